@@ -0,0 +1,997 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"modelgate/internal/audit"
+	"modelgate/internal/config"
+	"modelgate/internal/configsync"
+	"modelgate/internal/crypto"
+	"modelgate/internal/domain"
+	"modelgate/internal/litellm"
+	"modelgate/internal/passwords"
+	"modelgate/internal/provider"
+	"modelgate/internal/storage/postgres"
+	"modelgate/internal/tenantexport"
+)
+
+// cliCommand is an admin subcommand that operates directly against the Postgres store
+type cliCommand struct {
+	usage string
+	run   func(args []string) error
+}
+
+// cliCommands lists the admin subcommands; "apikey" and "provider" take a nested action
+var cliCommands = map[string]cliCommand{
+	"migrate":      {"migrate [-config path] [-dir migrations]", runMigrate},
+	"create-admin": {"create-admin -email addr [-name name] [-password pw]", runCreateAdmin},
+	"apikey":       {"apikey create -name name (-role role | -group id) [-expires 720h] | apikey revoke -id id [-reason text]", runAPIKey},
+	"provider":     {"provider set-key -provider name (-api-key key | -access-key-id id -secret-access-key secret) [-name name] [-priority n] [-replace]", runProvider},
+	"usage":        {"usage export [-from date] [-to date] [-format csv|json] [-out file] [-model m] [-api-key-id id] [-project-id id]", runUsage},
+	"encryption":   {"encryption rotate [-config path]", runEncryption},
+	"sync":         {"sync apply -f file [-dry-run] [-prune] | sync export [-out file]", runSync},
+	"import":       {"import litellm -f config.yaml [-dry-run]", runImport},
+	"tenant":       {"tenant export -out file [-passphrase pw] | tenant import -f file [-passphrase pw] [-on-conflict fail|skip|overwrite|rename] [-dry-run]", runTenant},
+}
+
+// cliActor is recorded in the audit log for changes made from the command line
+var cliActor = audit.Actor{ID: "cli", Email: "cli@localhost", Type: "system"}
+
+// runCLI runs the named subcommand and returns the process exit code
+func runCLI(name string, args []string) int {
+	// Logs go to stderr so that command output (keys, exports) can be piped
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn})))
+
+	if name == "help" || name == "-h" || name == "--help" {
+		printCLIUsage(os.Stdout)
+		return 0
+	}
+	cmd, ok := cliCommands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+		printCLIUsage(os.Stderr)
+		return 2
+	}
+	if err := cmd.run(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		fmt.Fprintf(os.Stderr, "modelgate %s: %v\n", name, err)
+		return 1
+	}
+	return 0
+}
+
+func printCLIUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: modelgate [-config path]            run the server")
+	fmt.Fprintln(w, "       modelgate <command> [flags]         run an admin command")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, name := range []string{"migrate", "create-admin", "apikey", "provider", "usage", "encryption", "sync", "import", "tenant"} {
+		fmt.Fprintf(w, "  %s\n", cliCommands[name].usage)
+	}
+}
+
+// newFlagSet returns a flag set with the shared -config flag
+func newFlagSet(name string) (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet("modelgate "+name, flag.ContinueOnError)
+	configPath := fs.String("config", "config.toml", "Path to configuration file")
+	return fs, configPath
+}
+
+// openStore loads the configuration and opens the Postgres store (applying pending migrations)
+func openStore(configPath string) (*config.Config, *postgres.Store, error) {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("load configuration: %w", err)
+	}
+	if cfg.Database.Driver != "postgres" {
+		return nil, nil, errors.New("only PostgreSQL storage is supported")
+	}
+	store, err := postgres.NewStore(&cfg.Database)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open database: %w", err)
+	}
+	return cfg, store, nil
+}
+
+// splitAction separates a nested action ("create", "revoke", ...) from its flags
+func splitAction(args []string, actions ...string) (string, []string, error) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return "", nil, fmt.Errorf("expected one of: %s", strings.Join(actions, ", "))
+	}
+	for _, a := range actions {
+		if args[0] == a {
+			return a, args[1:], nil
+		}
+	}
+	return "", nil, fmt.Errorf("unknown action %q, expected one of: %s", args[0], strings.Join(actions, ", "))
+}
+
+// =============================================================================
+// migrate
+// =============================================================================
+
+func runMigrate(args []string) error {
+	fs, configPath := newFlagSet("migrate")
+	dir := fs.String("dir", "migrations", "Directory containing *.sql migrations")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return fmt.Errorf("load configuration: %w", err)
+	}
+	if err := postgres.CreateDatabase(&cfg.Database, cfg.Database.Database); err != nil {
+		return err
+	}
+	db, err := postgres.NewDB(&cfg.Database, cfg.Database.GetDSN())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Unlike server startup, a failed migration is fatal here
+	if err := postgres.RunMigrations(db.GetDB(), *dir); err != nil {
+		return err
+	}
+	fmt.Println("Migrations applied")
+	return nil
+}
+
+// =============================================================================
+// create-admin
+// =============================================================================
+
+func runCreateAdmin(args []string) error {
+	fs, configPath := newFlagSet("create-admin")
+	email := fs.String("email", "", "Email address of the admin user (required)")
+	name := fs.String("name", "", "Display name (defaults to the email address)")
+	password := fs.String("password", "", "Password (defaults to $MODELGATE_ADMIN_PASSWORD, then a line read from stdin)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *email == "" {
+		return errors.New("-email is required")
+	}
+	if *name == "" {
+		*name = *email
+	}
+	if *password == "" {
+		*password = os.Getenv("MODELGATE_ADMIN_PASSWORD")
+	}
+	if *password == "" {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("read password: %w", err)
+		}
+		*password = strings.TrimRight(line, "\r\n")
+	}
+	cfg, store, err := openStore(*configPath)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	store.SetPasswordPolicy(passwords.NewPolicy(cfg.Passwords))
+
+	ctx := context.Background()
+	tenantStore := store.TenantStore()
+	if existing, _, err := tenantStore.GetUserByEmail(ctx, *email); err != nil {
+		return err
+	} else if existing != nil {
+		return fmt.Errorf("user %s already exists", *email)
+	}
+
+	// created_by references a user, so only the creator email is recorded
+	user, err := tenantStore.CreateUser(ctx, *email, *password, *name, "admin", "", cliActor.Email)
+	if err != nil {
+		return fmt.Errorf("create user: %w", err)
+	}
+	logCLIAudit(ctx, store, domain.AuditActionCreate, domain.AuditResourceUser, user.ID, user.Email, map[string]any{
+		"email": user.Email,
+		"name":  user.Name,
+		"role":  user.Role,
+	})
+
+	fmt.Printf("Created admin %s (%s)\n", user.Email, user.ID)
+	return nil
+}
+
+// =============================================================================
+// apikey create / revoke
+// =============================================================================
+
+func runAPIKey(args []string) error {
+	action, args, err := splitAction(args, "create", "revoke")
+	if err != nil {
+		return err
+	}
+	if action == "revoke" {
+		return runAPIKeyRevoke(args)
+	}
+	return runAPIKeyCreate(args)
+}
+
+func runAPIKeyCreate(args []string) error {
+	fs, configPath := newFlagSet("apikey create")
+	name := fs.String("name", "", "Name of the API key (required)")
+	role := fs.String("role", "", "Role name or ID to assign")
+	groupID := fs.String("group", "", "Group ID to assign (instead of a role)")
+	expires := fs.Duration("expires", 0, "Lifetime of the key, e.g. 720h (default: never expires)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *name == "" {
+		return errors.New("-name is required")
+	}
+	if (*role == "") == (*groupID == "") {
+		return errors.New("exactly one of -role or -group must be provided")
+	}
+
+	_, store, err := openStore(*configPath)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	tenantStore := store.TenantStore()
+
+	roleID := ""
+	if *role != "" {
+		r, err := tenantStore.GetRoleByName(ctx, *role)
+		if err != nil {
+			return err
+		}
+		if r == nil {
+			// Not a role name; an invalid UUID is reported as not found
+			r, _ = tenantStore.GetRole(ctx, *role)
+		}
+		if r == nil {
+			return fmt.Errorf("role %q not found", *role)
+		}
+		roleID = r.ID
+	} else if g, err := tenantStore.GetGroup(ctx, *groupID); err != nil || g == nil {
+		return fmt.Errorf("group %q not found", *groupID)
+	}
+
+	var expiresAt *time.Time
+	if *expires > 0 {
+		t := time.Now().Add(*expires)
+		expiresAt = &t
+	}
+
+	apiKey, fullKey, err := tenantStore.CreateAPIKey(ctx, *name, roleID, *groupID, []string{}, expiresAt)
+	if err != nil {
+		return fmt.Errorf("create API key: %w", err)
+	}
+	logCLIAudit(ctx, store, domain.AuditActionCreate, domain.AuditResourceAPIKey, apiKey.ID, apiKey.Name, map[string]any{
+		"name":       apiKey.Name,
+		"key_prefix": apiKey.KeyPrefix,
+		"role_id":    roleID,
+		"group_id":   *groupID,
+	})
+
+	// The key itself goes to stdout on its own line so scripts can capture it
+	fmt.Fprintf(os.Stderr, "Created API key %s (%s); it will not be shown again\n", apiKey.ID, apiKey.Name)
+	fmt.Println(fullKey)
+	return nil
+}
+
+func runAPIKeyRevoke(args []string) error {
+	fs, configPath := newFlagSet("apikey revoke")
+	id := fs.String("id", "", "ID of the API key to revoke (required)")
+	reason := fs.String("reason", "Revoked from CLI", "Reason recorded on the key")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *id == "" {
+		return errors.New("-id is required")
+	}
+
+	_, store, err := openStore(*configPath)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	tenantStore := store.TenantStore()
+	key, err := tenantStore.GetAPIKey(ctx, *id)
+	if err != nil || key == nil {
+		return fmt.Errorf("API key %q not found", *id)
+	}
+	if err := tenantStore.RevokeAPIKey(ctx, *id, *reason); err != nil {
+		return fmt.Errorf("revoke API key: %w", err)
+	}
+	logCLIAudit(ctx, store, domain.AuditActionRevoke, domain.AuditResourceAPIKey, key.ID, key.Name, map[string]any{
+		"reason": *reason,
+	})
+
+	fmt.Printf("Revoked API key %s (%s)\n", key.ID, key.Name)
+	return nil
+}
+
+// =============================================================================
+// provider set-key
+// =============================================================================
+
+func runProvider(args []string) error {
+	_, args, err := splitAction(args, "set-key")
+	if err != nil {
+		return err
+	}
+
+	fs, configPath := newFlagSet("provider set-key")
+	providerName := fs.String("provider", "", "Provider, e.g. openai, anthropic, bedrock (required)")
+	apiKey := fs.String("api-key", "", "Provider API key (defaults to $MODELGATE_PROVIDER_API_KEY)")
+	accessKeyID := fs.String("access-key-id", "", "AWS access key ID (Bedrock IAM credentials)")
+	secretAccessKey := fs.String("secret-access-key", "", "AWS secret access key (Bedrock IAM credentials)")
+	name := fs.String("name", "", "Name of the key")
+	priority := fs.Int("priority", 1, "Selection priority (lower is preferred)")
+	replace := fs.Bool("replace", false, "Delete the provider's existing keys first")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *providerName == "" {
+		return errors.New("-provider is required")
+	}
+	if *apiKey == "" {
+		*apiKey = os.Getenv("MODELGATE_PROVIDER_API_KEY")
+	}
+	if *apiKey == "" && (*accessKeyID == "" || *secretAccessKey == "") {
+		return errors.New("must provide either -api-key or both -access-key-id and -secret-access-key")
+	}
+
+	_, store, err := openStore(*configPath)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	tenantStore := store.TenantStore()
+	providerDomain := domain.Provider(strings.ToLower(*providerName))
+
+	// Keys are encrypted the same way as in the server, so the same key must be configured
+	getTenantDB := func(string) (*sql.DB, error) { return tenantStore.Pool().GetDB(), nil }
+	keySelector := provider.NewKeySelector(getTenantDB)
+	if encryptionKey := os.Getenv("MODELGATE_ENCRYPTION_KEY"); encryptionKey != "" {
+		encryptionService, err := crypto.NewEncryptionServiceFromString(encryptionKey)
+		if err != nil {
+			return fmt.Errorf("invalid MODELGATE_ENCRYPTION_KEY: %w", err)
+		}
+		keySelector = provider.NewKeySelectorWithEncryption(getTenantDB, encryptionService)
+	} else {
+		slog.Warn("No encryption key configured (MODELGATE_ENCRYPTION_KEY), the provider key will be stored in plain text")
+	}
+
+	if *replace {
+		existing, err := keySelector.ListKeys(ctx, "default", providerDomain)
+		if err != nil {
+			return fmt.Errorf("list existing keys: %w", err)
+		}
+		for _, k := range existing {
+			if err := keySelector.DeleteKey(ctx, "default", k.ID); err != nil {
+				return fmt.Errorf("delete key %s: %w", k.ID, err)
+			}
+		}
+	}
+
+	keyID, err := keySelector.StoreKey(ctx, "default", providerDomain, *apiKey, *name, *priority, *accessKeyID, *secretAccessKey)
+	if err != nil {
+		return err
+	}
+
+	// Make sure the provider is enabled so the key is used
+	providerConfig, err := tenantStore.GetProviderConfig(ctx, providerDomain)
+	if err != nil {
+		return fmt.Errorf("get provider config: %w", err)
+	}
+	if providerConfig == nil {
+		providerConfig = &domain.ProviderConfig{Provider: providerDomain, ConnectionSettings: domain.DefaultConnectionSettings()}
+	}
+	if !providerConfig.Enabled {
+		providerConfig.Enabled = true
+		if err := tenantStore.SaveProviderConfig(ctx, providerConfig); err != nil {
+			return fmt.Errorf("enable provider: %w", err)
+		}
+	}
+
+	logCLIAudit(ctx, store, domain.AuditActionUpdate, domain.AuditResourceProvider, keyID, string(providerDomain), map[string]any{
+		"key_name": *name,
+		"priority": *priority,
+		"replaced": *replace,
+	})
+
+	fmt.Printf("Stored %s key %s\n", providerDomain, keyID)
+	return nil
+}
+
+// =============================================================================
+// encryption rotate
+// =============================================================================
+
+// runEncryption re-encrypts stored MCP server credentials and content data
+// keys with the current MODELGATE_ENCRYPTION_KEY, using
+// MODELGATE_ENCRYPTION_PREVIOUS_KEYS to read envelopes sealed with an older key
+func runEncryption(args []string) error {
+	_, args, err := splitAction(args, "rotate")
+	if err != nil {
+		return err
+	}
+	fs, configPath := newFlagSet("encryption rotate")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	encryptionKey := os.Getenv("MODELGATE_ENCRYPTION_KEY")
+	if encryptionKey == "" {
+		return errors.New("MODELGATE_ENCRYPTION_KEY is required")
+	}
+	keyring, err := crypto.NewKeyringFromStrings(encryptionKey, os.Getenv("MODELGATE_ENCRYPTION_PREVIOUS_KEYS"))
+	if err != nil {
+		return fmt.Errorf("invalid encryption key: %w", err)
+	}
+
+	_, store, err := openStore(*configPath)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	store.SetEncryption(keyring)
+
+	n, err := store.TenantStore().ReencryptMCPAuthConfigs(context.Background())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Re-encrypted %d MCP server auth configs with key %s\n", n, keyring.PrimaryKeyID())
+
+	n, err = store.TenantStore().ReencryptContentKeys(context.Background())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Re-encrypted %d content keys with key %s\n", n, keyring.PrimaryKeyID())
+	return nil
+}
+
+// =============================================================================
+// sync apply / export
+// =============================================================================
+
+func runSync(args []string) error {
+	action, args, err := splitAction(args, "apply", "export")
+	if err != nil {
+		return err
+	}
+	if action == "export" {
+		return runSyncExport(args)
+	}
+	return runSyncApply(args)
+}
+
+func runSyncApply(args []string) error {
+	fs, configPath := newFlagSet("sync apply")
+	file := fs.String("f", "", "YAML or JSON document to apply, - for stdin (required)")
+	dryRun := fs.Bool("dry-run", false, "Report the changes without making them")
+	prune := fs.Bool("prune", false, "Delete roles and groups that are not in the document")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return errors.New("-f is required")
+	}
+
+	var data []byte
+	var err error
+	if *file == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(*file)
+	}
+	if err != nil {
+		return err
+	}
+	doc, err := configsync.Parse(data)
+	if err != nil {
+		return err
+	}
+
+	_, store, err := openStore(*configPath)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	changes, err := configsync.NewSyncer(store.TenantStore()).Apply(ctx, doc, configsync.Options{
+		DryRun:     *dryRun,
+		Prune:      *prune,
+		ActorEmail: cliActor.Email,
+	})
+	// Changes made before a failure are still reported and audited
+	for _, c := range changes {
+		fmt.Println(c)
+		if !*dryRun {
+			logCLIAudit(ctx, store, domain.AuditAction(c.Action), domain.AuditResourceType(c.Kind), c.ID, c.Name, map[string]any{
+				"source": "sync",
+				"fields": c.Fields,
+			})
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case len(changes) == 0:
+		fmt.Println("No changes; the database matches the document")
+	case *dryRun:
+		fmt.Printf("%d changes would be made (dry run)\n", len(changes))
+	default:
+		fmt.Printf("Applied %d changes\n", len(changes))
+	}
+	return nil
+}
+
+func runSyncExport(args []string) error {
+	fs, configPath := newFlagSet("sync export")
+	out := fs.String("out", "", "Output file (default: stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	_, store, err := openStore(*configPath)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	doc, err := configsync.NewSyncer(store.TenantStore()).Export(context.Background())
+	if err != nil {
+		return err
+	}
+	data, err := doc.Marshal()
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(*out, data, 0o644)
+}
+
+// =============================================================================
+// import litellm
+// =============================================================================
+
+func runImport(args []string) error {
+	_, args, err := splitAction(args, "litellm")
+	if err != nil {
+		return err
+	}
+	fs, configPath := newFlagSet("import litellm")
+	file := fs.String("f", "", "LiteLLM config.yaml to import, - for stdin (required)")
+	dryRun := fs.Bool("dry-run", false, "Report what would be created without creating it")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return errors.New("-f is required")
+	}
+
+	var data []byte
+	if *file == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(*file)
+	}
+	if err != nil {
+		return err
+	}
+	cfg, err := litellm.Parse(data)
+	if err != nil {
+		return err
+	}
+
+	_, store, err := openStore(*configPath)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	report, err := litellm.NewImporter(store.TenantStore()).Import(ctx, cfg, litellm.Options{
+		DryRun:     *dryRun,
+		ActorEmail: cliActor.Email,
+	})
+	if report == nil {
+		return err
+	}
+	// Changes made before a failure are still reported and audited
+	for _, c := range report.Changes {
+		fmt.Println(c)
+		if !*dryRun {
+			logCLIAudit(ctx, store, domain.AuditAction(c.Action), domain.AuditResourceType(c.Kind), c.ID, c.Name, map[string]any{
+				"source": "litellm_import",
+				"fields": c.Fields,
+			})
+		}
+	}
+	for _, w := range report.Warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", w)
+	}
+	if err != nil {
+		return err
+	}
+
+	if aliases := report.SortedAliases(); len(aliases) > 0 {
+		fmt.Println()
+		fmt.Println("# Add to config.toml so clients keep their LiteLLM model names:")
+		fmt.Println("[aliases]")
+		for _, line := range aliases {
+			fmt.Println(line)
+		}
+		fmt.Println()
+	}
+	switch {
+	case len(report.Changes) == 0:
+		fmt.Println("Nothing to import; everything in the config already exists")
+	case *dryRun:
+		fmt.Printf("%d changes would be made (dry run)\n", len(report.Changes))
+	default:
+		fmt.Printf("Imported %d changes\n", len(report.Changes))
+	}
+	return nil
+}
+
+// =============================================================================
+// tenant export / import
+// =============================================================================
+
+func runTenant(args []string) error {
+	action, args, err := splitAction(args, "export", "import")
+	if err != nil {
+		return err
+	}
+	if action == "export" {
+		return runTenantExport(args)
+	}
+	return runTenantImport(args)
+}
+
+// openTenantExport opens the store and provider keys with the instance's
+// encryption key, so secrets are read and written the way the server does
+func openTenantExport(configPath string) (*postgres.Store, *tenantexport.Service, error) {
+	_, store, err := openStore(configPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	tenantStore := store.TenantStore()
+	getTenantDB := func(string) (*sql.DB, error) { return tenantStore.Pool().GetDB(), nil }
+	keySelector := provider.NewKeySelector(getTenantDB)
+	if encryptionKey := os.Getenv("MODELGATE_ENCRYPTION_KEY"); encryptionKey != "" {
+		keyring, err := crypto.NewKeyringFromStrings(encryptionKey, os.Getenv("MODELGATE_ENCRYPTION_PREVIOUS_KEYS"))
+		if err != nil {
+			store.Close()
+			return nil, nil, fmt.Errorf("invalid encryption key: %w", err)
+		}
+		encryptionService, err := crypto.NewEncryptionServiceFromString(encryptionKey)
+		if err != nil {
+			store.Close()
+			return nil, nil, fmt.Errorf("invalid MODELGATE_ENCRYPTION_KEY: %w", err)
+		}
+		store.SetEncryption(keyring)
+		keySelector = provider.NewKeySelectorWithEncryption(getTenantDB, encryptionService)
+	} else {
+		slog.Warn("No encryption key configured (MODELGATE_ENCRYPTION_KEY), provider keys and MCP credentials are read and stored in plain text")
+	}
+	return store, tenantexport.NewService(tenantStore, keySelector, "default"), nil
+}
+
+func runTenantExport(args []string) error {
+	fs, configPath := newFlagSet("tenant export")
+	out := fs.String("out", "", "Archive to write, - for stdout (required)")
+	passphrase := fs.String("passphrase", "", "Passphrase sealing provider keys and MCP credentials (defaults to $MODELGATE_EXPORT_PASSPHRASE); without one they are left out")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *out == "" {
+		return errors.New("-out is required")
+	}
+	if *passphrase == "" {
+		*passphrase = os.Getenv("MODELGATE_EXPORT_PASSPHRASE")
+	}
+
+	store, svc, err := openTenantExport(*configPath)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	archive, err := svc.Export(ctx, tenantexport.ExportOptions{Passphrase: *passphrase})
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := archive.Write(&buf); err != nil {
+		return err
+	}
+	if *out == "-" {
+		_, err = os.Stdout.Write(buf.Bytes())
+	} else {
+		err = os.WriteFile(*out, buf.Bytes(), 0o600)
+	}
+	if err != nil {
+		return err
+	}
+
+	counts := make(map[string]any, len(archive.Manifest.Sections))
+	for name, s := range archive.Manifest.Sections {
+		counts[strings.TrimSuffix(name, ".json")] = s.Count
+	}
+	logCLIAudit(ctx, store, domain.AuditActionExport, domain.AuditResourceTenant, "default", "default", map[string]any{
+		"source":  "tenant_export",
+		"counts":  counts,
+		"secrets": archive.Manifest.Secrets != nil,
+	})
+
+	for _, o := range archive.Manifest.Omitted {
+		fmt.Fprintf(os.Stderr, "left out: %s\n", o)
+	}
+	if *out != "-" {
+		fmt.Printf("Exported %d roles, %d groups, %d providers, %d provider keys, %d API keys, %d custom models, %d model configs and %d MCP servers to %s\n",
+			len(archive.Roles), len(archive.Groups), len(archive.Providers), len(archive.ProviderKeys), len(archive.APIKeys),
+			len(archive.CustomModels), len(archive.ModelConfigs), len(archive.MCPServers), *out)
+	}
+	return nil
+}
+
+func runTenantImport(args []string) error {
+	fs, configPath := newFlagSet("tenant import")
+	file := fs.String("f", "", "Archive to import, - for stdin (required)")
+	passphrase := fs.String("passphrase", "", "Passphrase the archive was exported with (defaults to $MODELGATE_EXPORT_PASSPHRASE)")
+	onConflict := fs.String("on-conflict", "fail", "What to do with entries that already exist: fail, skip, overwrite or rename")
+	dryRun := fs.Bool("dry-run", false, "Report the changes without making them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return errors.New("-f is required")
+	}
+	mode, err := tenantexport.ParseConflictMode(*onConflict)
+	if err != nil {
+		return err
+	}
+	if *passphrase == "" {
+		*passphrase = os.Getenv("MODELGATE_EXPORT_PASSPHRASE")
+	}
+
+	var r io.Reader = os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	archive, err := tenantexport.ReadArchive(r)
+	if err != nil {
+		return err
+	}
+
+	store, svc, err := openTenantExport(*configPath)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	report, err := svc.Import(ctx, archive, tenantexport.ImportOptions{
+		Passphrase: *passphrase,
+		OnConflict: mode,
+		DryRun:     *dryRun,
+		ActorEmail: cliActor.Email,
+	})
+	if report == nil {
+		return err
+	}
+	for _, c := range report.Conflicts {
+		fmt.Fprintf(os.Stderr, "conflict: %s\n", c)
+	}
+	// Changes made before a failure are still reported and audited
+	for _, c := range report.Changes {
+		fmt.Println(c)
+		if !*dryRun {
+			logCLIAudit(ctx, store, domain.AuditAction(c.Action), domain.AuditResourceType(c.Kind), c.ID, c.Name, map[string]any{
+				"source":      "tenant_import",
+				"exported_at": archive.Manifest.ExportedAt,
+				"fields":      c.Fields,
+			})
+		}
+	}
+	for _, w := range report.Warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", w)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *dryRun {
+		fmt.Printf("%d changes would be made (dry run)\n", len(report.Changes))
+		return nil
+	}
+	fmt.Printf("Imported %d changes\n\nVerification:\n", len(report.Changes))
+	for _, c := range report.Verification {
+		fmt.Printf("  %-28s %d/%d\n", c.Section, c.Verified, c.Expected)
+		for _, p := range c.Problems {
+			fmt.Printf("    - %s\n", p)
+		}
+	}
+	if !report.Verified() {
+		return errors.New("the imported tenant doesn't match the archive")
+	}
+	return nil
+}
+
+// =============================================================================
+// usage export
+// =============================================================================
+
+func runUsage(args []string) error {
+	_, args, err := splitAction(args, "export")
+	if err != nil {
+		return err
+	}
+
+	fs, configPath := newFlagSet("usage export")
+	from := fs.String("from", "", "Start date (YYYY-MM-DD or RFC 3339, default: 30 days ago)")
+	to := fs.String("to", "", "End date (YYYY-MM-DD or RFC 3339, default: now)")
+	format := fs.String("format", "csv", "Output format: csv or json (JSON lines)")
+	out := fs.String("out", "", "Output file (default: stdout)")
+	model := fs.String("model", "", "Only export records for this model")
+	apiKeyID := fs.String("api-key-id", "", "Only export records for this API key")
+	projectID := fs.String("project-id", "", "Only export records for this project")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *format != "csv" && *format != "json" {
+		return fmt.Errorf("unsupported format %q", *format)
+	}
+
+	endTime := time.Now()
+	startTime := endTime.AddDate(0, 0, -30)
+	if *from != "" {
+		if startTime, err = parseCLITime(*from, false); err != nil {
+			return err
+		}
+	}
+	if *to != "" {
+		if endTime, err = parseCLITime(*to, true); err != nil {
+			return err
+		}
+	}
+
+	_, store, err := openStore(*configPath)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	records, err := store.TenantStore().ListUsageRecords(context.Background(), startTime, endTime, *model, "", *apiKeyID, *projectID, "", 0)
+	if err != nil {
+		return fmt.Errorf("list usage records: %w", err)
+	}
+
+	w := io.Writer(os.Stdout)
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if *format == "json" {
+		err = writeUsageJSON(w, records)
+	} else {
+		err = writeUsageCSV(w, records)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d usage records\n", len(records))
+	return nil
+}
+
+// parseCLITime accepts a date or an RFC 3339 timestamp; a bare end date covers the whole day
+func parseCLITime(s string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, expected YYYY-MM-DD or RFC 3339", s)
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return t, nil
+}
+
+func writeUsageJSON(w io.Writer, records []*domain.UsageRecord) error {
+	enc := json.NewEncoder(w)
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeUsageCSV(w io.Writer, records []*domain.UsageRecord) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{
+		"timestamp", "id", "request_id", "api_key_id", "api_key_name", "model", "provider",
+		"input_tokens", "output_tokens", "total_tokens", "thinking_tokens", "cost_usd",
+		"latency_ms", "success", "error_code", "tool_calls",
+	})
+	for _, r := range records {
+		cw.Write([]string{
+			r.Timestamp.UTC().Format(time.RFC3339),
+			r.ID,
+			r.RequestID,
+			r.APIKeyID,
+			r.APIKeyName,
+			r.Model,
+			string(r.Provider),
+			strconv.FormatInt(r.InputTokens, 10),
+			strconv.FormatInt(r.OutputTokens, 10),
+			strconv.FormatInt(r.TotalTokens, 10),
+			strconv.FormatInt(r.ThinkingTokens, 10),
+			strconv.FormatFloat(r.CostUSD, 'f', 6, 64),
+			strconv.FormatInt(r.LatencyMs, 10),
+			strconv.FormatBool(r.Success),
+			r.ErrorCode,
+			strconv.Itoa(int(r.ToolCalls)),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// logCLIAudit records a change made from the command line; failures only warn
+func logCLIAudit(ctx context.Context, store *postgres.Store, action domain.AuditAction, resourceType domain.AuditResourceType, id, name string, newValue map[string]any) {
+	err := audit.NewService(store).LogSuccess(ctx, audit.LogEntry{
+		TenantSlug:   "default",
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceID:   id,
+		ResourceName: name,
+		Actor:        cliActor,
+		UserAgent:    "modelgate-cli",
+		NewValue:     newValue,
+	})
+	if err != nil {
+		slog.Warn("Failed to write audit log", "error", err)
+	}
+}
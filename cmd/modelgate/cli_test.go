@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+func TestSplitAction(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		action   string
+		rest     []string
+		errorMsg string
+	}{
+		{name: "action with flags", args: []string{"create", "--name", "ci"}, action: "create", rest: []string{"--name", "ci"}},
+		{name: "action alone", args: []string{"list"}, action: "list", rest: []string{}},
+		{name: "no arguments", args: nil, errorMsg: "expected one of: create, list, revoke"},
+		{name: "flag first", args: []string{"--name", "ci"}, errorMsg: "expected one of: create, list, revoke"},
+		{name: "unknown action", args: []string{"delete"}, errorMsg: `unknown action "delete", expected one of: create, list, revoke`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			action, rest, err := splitAction(tt.args, "create", "list", "revoke")
+			if tt.errorMsg != "" {
+				if err == nil || err.Error() != tt.errorMsg {
+					t.Fatalf("expected error %q, got %v", tt.errorMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if action != tt.action || !reflect.DeepEqual(rest, tt.rest) {
+				t.Fatalf("got %q %v, want %q %v", action, rest, tt.action, tt.rest)
+			}
+		})
+	}
+}
+
+func TestParseCLITime(t *testing.T) {
+	got, err := parseCLITime("2026-03-04T05:06:07Z", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC); !got.Equal(want) {
+		t.Fatalf("expected an RFC 3339 time as given, even at end of day, got %v", got)
+	}
+
+	got, err = parseCLITime("2026-03-04", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2026, 3, 4, 0, 0, 0, 0, time.Local); !got.Equal(want) {
+		t.Fatalf("expected the start of the local day, got %v", got)
+	}
+
+	// A date as the end of a range includes the whole day
+	got, err = parseCLITime("2026-03-04", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2026, 3, 5, 0, 0, 0, 0, time.Local).Add(-time.Nanosecond); !got.Equal(want) {
+		t.Fatalf("expected the last instant of the local day, got %v", got)
+	}
+
+	// The last day of a month and of a year roll over correctly
+	got, err = parseCLITime("2026-12-31", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Year() != 2026 || got.Month() != time.December || got.Day() != 31 || got.Hour() != 23 {
+		t.Fatalf("expected the end of 31 December, got %v", got)
+	}
+
+	for _, s := range []string{"", "04/03/2026", "2026-13-01", "yesterday"} {
+		if _, err := parseCLITime(s, false); err == nil || !strings.Contains(err.Error(), "expected YYYY-MM-DD or RFC 3339") {
+			t.Errorf("%q: expected a parse error, got %v", s, err)
+		}
+	}
+}
+
+func testUsageRecords() []*domain.UsageRecord {
+	return []*domain.UsageRecord{
+		{
+			ID: "rec-1", RequestID: "req-1", APIKeyID: "key-1", APIKeyName: "ci, nightly", Model: "gpt-4o",
+			Provider: domain.ProviderOpenAI, InputTokens: 100, OutputTokens: 20, TotalTokens: 120, ThinkingTokens: 5,
+			CostUSD: 0.0123456789, LatencyMs: 850, Success: true, ToolCalls: 2,
+			Timestamp: time.Date(2026, 3, 4, 5, 6, 7, 0, time.FixedZone("CET", 3600)),
+		},
+		{
+			ID: "rec-2", RequestID: "req-2", Model: "gpt-4o", Provider: domain.ProviderOpenAI,
+			Success: false, ErrorCode: "rate_limited", Timestamp: time.Date(2026, 3, 4, 6, 0, 0, 0, time.UTC),
+		},
+	}
+}
+
+func TestWriteUsageCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeUsageCSV(&buf, testUsageRecords()); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"timestamp", "id", "request_id", "api_key_id", "api_key_name", "model", "provider",
+			"input_tokens", "output_tokens", "total_tokens", "thinking_tokens", "cost_usd",
+			"latency_ms", "success", "error_code", "tool_calls"},
+		{"2026-03-04T04:06:07Z", "rec-1", "req-1", "key-1", "ci, nightly", "gpt-4o", "openai",
+			"100", "20", "120", "5", "0.012346", "850", "true", "", "2"},
+		{"2026-03-04T06:00:00Z", "rec-2", "req-2", "", "", "gpt-4o", "openai",
+			"0", "0", "0", "0", "0.000000", "0", "false", "rate_limited", "0"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Fatalf("unexpected CSV:\n got %q\nwant %q", rows, want)
+	}
+
+	buf.Reset()
+	if err := writeUsageCSV(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != 1 {
+		t.Fatalf("expected only the header without records, got %d lines", lines)
+	}
+}
+
+func TestWriteUsageJSON(t *testing.T) {
+	var buf bytes.Buffer
+	records := testUsageRecords()
+	if err := writeUsageJSON(&buf, records); err != nil {
+		t.Fatal(err)
+	}
+
+	// One record per line
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != len(records) {
+		t.Fatalf("expected %d lines, got %d: %q", len(records), len(lines), buf.String())
+	}
+	for i, line := range lines {
+		var got domain.UsageRecord
+		if err := json.Unmarshal([]byte(line), &got); err != nil {
+			t.Fatalf("line %d: %v", i, err)
+		}
+		if got.ID != records[i].ID || got.TotalTokens != records[i].TotalTokens || got.ErrorCode != records[i].ErrorCode ||
+			!got.Timestamp.Equal(records[i].Timestamp) {
+			t.Fatalf("line %d: got %+v, want %+v", i, got, *records[i])
+		}
+	}
+
+	buf.Reset()
+	if err := writeUsageJSON(&buf, nil); err != nil || buf.Len() != 0 {
+		t.Fatalf("expected no output without records, got %q, %v", buf.String(), err)
+	}
+}
@@ -10,25 +10,69 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"reflect"
+	"strings"
 	"syscall"
 	"time"
 
+	"modelgate/internal/analytics"
+	"modelgate/internal/assistants"
+	"modelgate/internal/attestation"
+	"modelgate/internal/audit"
+	"modelgate/internal/auditexport"
+	"modelgate/internal/batch"
 	"modelgate/internal/cache/embedding"
 	"modelgate/internal/cache/semantic"
 	"modelgate/internal/config"
+	"modelgate/internal/convbudget"
 	"modelgate/internal/crypto"
+	"modelgate/internal/customdomains"
+	"modelgate/internal/custommodels"
+	"modelgate/internal/dataaudit"
+	"modelgate/internal/deprecation"
+	"modelgate/internal/digest"
+	"modelgate/internal/discovery"
+	"modelgate/internal/documents"
+	"modelgate/internal/domain"
+	"modelgate/internal/email"
+	"modelgate/internal/embedders"
+	"modelgate/internal/eventstream"
+	"modelgate/internal/experiments"
+	"modelgate/internal/featureflags"
+	"modelgate/internal/feedback"
+	"modelgate/internal/files"
+	"modelgate/internal/finetune"
 	"modelgate/internal/gateway"
+	"modelgate/internal/healthhistory"
 	httpserver "modelgate/internal/http"
+	"modelgate/internal/idempotency"
+	"modelgate/internal/injection"
+	"modelgate/internal/keybudget"
+	"modelgate/internal/killswitch"
 	"modelgate/internal/mcp"
+	"modelgate/internal/objectstore"
+	"modelgate/internal/ollama"
+	"modelgate/internal/passwords"
 	"modelgate/internal/policy"
+	"modelgate/internal/pricing"
+	"modelgate/internal/projects"
 	"modelgate/internal/provider"
+	"modelgate/internal/quota"
+	"modelgate/internal/readiness"
+	"modelgate/internal/registration"
+	"modelgate/internal/replay"
 	"modelgate/internal/resilience"
 	"modelgate/internal/responses"
+	"modelgate/internal/retention"
 	"modelgate/internal/routing"
 	"modelgate/internal/routing/health"
 	"modelgate/internal/storage"
 	"modelgate/internal/storage/postgres"
 	"modelgate/internal/telemetry"
+	"modelgate/internal/threads"
+	"modelgate/internal/toolapproval"
+	"modelgate/internal/twofactor"
+	"modelgate/internal/usageexport"
 )
 
 // openAIEmbeddingAdapter adapts OpenAI embedder to embedding.EmbeddingClient interface
@@ -36,10 +80,13 @@ type openAIEmbeddingAdapter struct {
 	embedder *mcp.OpenAIEmbedder
 }
 
-func newOpenAIEmbeddingAdapter(apiKey, model string) *openAIEmbeddingAdapter {
+func newOpenAIEmbeddingAdapter(apiKey, baseURL, model string) *openAIEmbeddingAdapter {
 	embedder := mcp.NewOpenAIEmbedder(apiKey)
-	if model != "" {
-		embedder = mcp.NewOpenAIEmbedderWithBaseURL(apiKey, "", model)
+	if model != "" || baseURL != "" {
+		if baseURL == "" {
+			baseURL = openAIEmbeddingsURL
+		}
+		embedder = mcp.NewOpenAIEmbedderWithBaseURL(apiKey, baseURL, model)
 	}
 	return &openAIEmbeddingAdapter{embedder: embedder}
 }
@@ -66,13 +113,19 @@ func (a *ollamaEmbeddingAdapter) Embed(ctx context.Context, texts []string) ([][
 }
 
 func main() {
+	// Admin subcommands (modelgate migrate, modelgate apikey create, ...)
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		os.Exit(runCLI(os.Args[1], os.Args[2:]))
+	}
+
 	// Parse command line flags
 	configPath := flag.String("config", "config.toml", "Path to configuration file")
 	flag.Parse()
 
-	// Setup structured logging
+	// Setup structured logging (the level follows telemetry.log_level and is reloadable)
+	logLevel := new(slog.LevelVar)
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
+		Level: logLevel,
 	}))
 	slog.SetDefault(logger)
 
@@ -82,6 +135,12 @@ func main() {
 		slog.Error("Failed to load configuration", "error", err)
 		os.Exit(1)
 	}
+	if err := cfg.Validate(); err != nil {
+		slog.Error("Invalid configuration", "error", err)
+		os.Exit(1)
+	}
+	logLevel.Set(parseLogLevel(cfg.Telemetry.LogLevel))
+	configHolder := config.NewHolder(*configPath, cfg)
 
 	slog.Info("Starting ModelGate",
 		"version", "0.1.0",
@@ -116,6 +175,8 @@ func main() {
 		os.Exit(1)
 	}
 	defer pgStore.Close()
+	passwordPolicy := passwords.NewPolicy(cfg.Passwords)
+	pgStore.SetPasswordPolicy(passwordPolicy)
 
 	// Initialize memory store for policy repository (pending migration to PostgreSQL)
 	memStore = storage.NewMemoryStore()
@@ -126,6 +187,9 @@ func main() {
 	if err != nil {
 		slog.Warn("Provider manager warning", "error", err)
 	}
+	if metrics != nil {
+		providerManager.SetPoolObserver(metrics)
+	}
 
 	// Log registered providers
 	for _, p := range providerManager.AvailableProviders() {
@@ -154,38 +218,45 @@ func main() {
 		slog.Warn("No encryption key configured (MODELGATE_ENCRYPTION_KEY), API keys will be stored in plain text")
 	}
 
-	// Initialize semantic caching services
-	// 1. Embedding service for semantic similarity
-	// Supports both Ollama (default) and OpenAI embedders
-	var embeddingClient embedding.EmbeddingClient
-	switch cfg.Embedder.Type {
-	case "openai":
-		if cfg.Embedder.APIKey != "" {
-			embeddingClient = newOpenAIEmbeddingAdapter(cfg.Embedder.APIKey, cfg.Embedder.Model)
-			slog.Info("Semantic cache: using OpenAI embeddings", "model", cfg.Embedder.Model)
-		} else {
-			slog.Warn("Semantic cache: OpenAI embedder configured but no API key provided")
+	// MCP server credentials, attestation signing keys and the tenant data key
+	// for captured content are envelope-encrypted with the same key. Keys listed in
+	// MODELGATE_ENCRYPTION_PREVIOUS_KEYS still decrypt existing envelopes, which
+	// are re-wrapped with the current key on startup.
+	var contentCipher *crypto.ContentCipher
+	if encryptionService != nil {
+		keyring, err := crypto.NewKeyringFromStrings(encryptionKey, os.Getenv("MODELGATE_ENCRYPTION_PREVIOUS_KEYS"))
+		if err != nil {
+			slog.Error("Invalid MODELGATE_ENCRYPTION_PREVIOUS_KEYS", "error", err)
+			os.Exit(1)
 		}
-	case "ollama":
-		baseURL := cfg.Embedder.BaseURL
-		if baseURL == "" {
-			baseURL = "http://localhost:11434"
+		pgStore.SetEncryption(keyring)
+		if n, err := pgStore.TenantStore().ReencryptMCPAuthConfigs(context.Background()); err != nil {
+			slog.Error("Failed to encrypt MCP server auth configs", "error", err)
+		} else if n > 0 {
+			slog.Info("Encrypted MCP server auth configs with the current key", "servers", n, "key_id", keyring.PrimaryKeyID())
+		}
+		if n, err := pgStore.TenantStore().ReencryptContentKeys(context.Background()); err != nil {
+			slog.Error("Failed to re-wrap content keys", "error", err)
+		} else if n > 0 {
+			slog.Info("Re-wrapped content keys with the current key", "keys", n, "key_id", keyring.PrimaryKeyID())
 		}
-		model := cfg.Embedder.Model
-		if model == "" {
-			model = "nomic-embed-text"
+		if contentCipher, err = pgStore.TenantStore().ContentCipher(context.Background()); err != nil {
+			slog.Error("Failed to load content encryption key", "error", err)
+			os.Exit(1)
 		}
-		embeddingClient = newOllamaEmbeddingAdapter(baseURL, model)
-		slog.Info("Semantic cache: using Ollama embeddings", "url", baseURL, "model", model)
-	default:
-		// Default to Ollama with nomic-embed-text
-		embeddingClient = newOllamaEmbeddingAdapter("http://localhost:11434", "nomic-embed-text")
-		slog.Info("Semantic cache: using default Ollama embeddings", "model", "nomic-embed-text")
+		slog.Info("Captured prompts and responses are encrypted at rest", "data_key_id", contentCipher.KeyID())
 	}
+
+	// Initialize semantic caching services
+	// 1. Embedding service for semantic similarity (swappable on config reload)
+	embeddingClient := newSwappableEmbeddingClient(newCacheEmbeddingClient(cfg.Embedder))
 	embeddingService := embedding.NewEmbeddingService(embeddingClient, cfg.Embedder.Model)
 
 	// 2. Semantic cache service (single-tenant mode)
 	semanticCacheService := semantic.NewTenantAwareService(pgStore.DB().GetDB(), embeddingService)
+	if contentCipher != nil {
+		semanticCacheService.SetContentCipher(contentCipher)
+	}
 	slog.Info("Semantic cache service initialized")
 
 	// Initialize intelligent routing services
@@ -194,11 +265,18 @@ func main() {
 
 	// 2. Router with health tracking
 	router := routing.NewRouter(healthTracker)
+	configureStickyRouting(router, cfg.StickyRouting)
 	slog.Info("Intelligent routing service initialized")
 
 	// Initialize resilience services
 	// 1. Circuit breaker
 	circuitBreaker := resilience.NewCircuitBreaker(pgStore.DB().GetDB())
+	circuitBreaker.SetTransitionHook(func(tenantID, provider string, from, to resilience.CircuitState) {
+		healthTracker.RecordBreakerState(provider, string(to))
+		if metrics != nil {
+			metrics.RecordCircuitBreakerTransition(provider, tenantID, string(from), string(to))
+		}
+	})
 
 	// 2. Resilience service
 	resilienceService := resilience.NewService(circuitBreaker)
@@ -211,7 +289,7 @@ func main() {
 		if err != nil {
 			return nil, err
 		}
-		return tenantStore.DB().GetDB(), nil
+		return tenantStore.Pool().GetDB(), nil
 	}
 
 	var keySelector *provider.KeySelector
@@ -220,6 +298,7 @@ func main() {
 	} else {
 		keySelector = provider.NewKeySelector(getTenantDB)
 	}
+	keySelector.SetQuotaSource(healthTracker)
 	slog.Info("Multi-key selector initialized", "encryption_enabled", encryptionService != nil)
 
 	// Initialize gateway service with all new services
@@ -238,66 +317,26 @@ func main() {
 	)
 
 	// Initialize adaptive dispatcher with channel-based queuing
-	dispatcherConfig := gateway.DefaultDispatcherConfig()
-	// Override from config if needed
-	if cfg.Server.MinWorkers > 0 {
-		dispatcherConfig.MinWorkers = cfg.Server.MinWorkers
-	}
-	if cfg.Server.MaxWorkers > 0 {
-		dispatcherConfig.MaxWorkers = cfg.Server.MaxWorkers
-	}
-	if cfg.Server.MaxQueuedRequests > 0 {
-		dispatcherConfig.MaxQueuedRequests = cfg.Server.MaxQueuedRequests
-	}
-	if cfg.Server.ScaleUpThreshold > 0 {
-		dispatcherConfig.ScaleUpThreshold = cfg.Server.ScaleUpThreshold
-	}
-	if cfg.Server.ScaleDownThreshold > 0 {
-		dispatcherConfig.ScaleDownThreshold = cfg.Server.ScaleDownThreshold
-	}
-
+	dispatcherConfig := dispatcherConfigFor(cfg.Server)
 	dispatcher := gateway.NewDispatcher(dispatcherConfig, gatewayService)
+	if cfg.Server.DurableQueue {
+		dispatcher.SetDurableQueue(pgStore.TenantStore(), cfg.Server.DurableQueueRetention)
+	}
 	dispatcher.Start()
 
 	// Create context for graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// Handle shutdown signals
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-	go func() {
-		sig := <-sigChan
-		slog.Info("Received shutdown signal", "signal", sig)
-		dispatcher.Stop() // Stop dispatcher first
-		cancel()
-	}()
-
 	// Initialize MCP Gateway and Server BEFORE starting HTTP server
-	// Create embedder based on config for semantic tool search
-	var embedder mcp.Embedder
-	switch cfg.Embedder.Type {
-	case "openai":
-		if cfg.Embedder.BaseURL != "" {
-			embedder = mcp.NewOpenAIEmbedderWithBaseURL(cfg.Embedder.APIKey, cfg.Embedder.BaseURL, cfg.Embedder.Model)
-		} else {
-			embedder = mcp.NewOpenAIEmbedder(cfg.Embedder.APIKey)
-		}
-		slog.Info("Using OpenAI embedder", "model", cfg.Embedder.Model)
-	case "ollama":
-		embedder = mcp.NewOllamaEmbedder(cfg.Embedder.BaseURL, cfg.Embedder.Model)
-		slog.Info("Using Ollama embedder", "url", cfg.Embedder.BaseURL, "model", cfg.Embedder.Model)
-	default:
-		// Default to Ollama with nomic-embed-text
-		embedder = mcp.NewOllamaEmbedder("http://localhost:11434", "nomic-embed-text")
-		slog.Info("Using default Ollama embedder", "model", "nomic-embed-text")
-	}
+	// Create embedder based on config for semantic tool search (swappable on config reload)
+	embedder := newSwappableEmbedder(newToolSearchEmbedder(cfg.Embedder))
 	mcpGateway := mcp.NewGateway(embedder)
 	mcpServer := mcp.NewMCPServer(mcpGateway, pgStore)
 
 	// Initialize responses service for /v1/responses endpoint (structured outputs)
 	// Uses provider manager to dynamically resolve providers based on tenant configuration
-	responsesService := responses.NewService(cfg, providerManager, pgStore)
+	responsesService := responses.NewService(cfg, providerManager, pgStore, metrics)
 
 	// Start unified HTTP server (OpenAI API + GraphQL)
 	httpAddr := fmt.Sprintf(":%d", cfg.Server.HTTPPort)
@@ -305,7 +344,405 @@ func main() {
 	// Set MCP Server and Gateway
 	httpServer.SetMCPServer(mcpServer)
 	httpServer.SetMCPGateway(mcpGateway)
+
+	// Handle shutdown signals
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		sig := <-sigChan
+		slog.Info("Received shutdown signal, draining", "signal", sig)
+
+		// Let in-flight requests, streams and queued work finish; a second
+		// signal stops waiting
+		drainCtx, stopDraining := context.WithCancel(context.Background())
+		go func() {
+			select {
+			case <-sigChan:
+				stopDraining()
+			case <-drainCtx.Done():
+			}
+		}()
+		status := httpServer.Drain(drainCtx)
+		stopDraining()
+		slog.Info("Drain finished", "complete", status.Complete, "in_flight", status.InFlightRequests,
+			"streams", status.ActiveStreams, "queued", status.QueuedRequests)
+
+		dispatcher.Stop()
+		cancel()
+	}()
+
+	// Injection detection by similarity to a corpus of known attacks
+	injectionEmbedder := newSwappableEmbeddingClient(newCacheEmbeddingClient(cfg.Embedder))
+	injectionService := injection.NewService(pgStore.TenantStore(), injectionEmbedder)
+	gatewayService.SetInjectionMatcher(injectionService)
+	httpServer.SetInjection(injectionService)
+
+	// Documents ingested for retrieval, with images transcribed by the OCR model
+	documentsEmbedder := newSwappableEmbeddingClient(newCacheEmbeddingClient(cfg.Embedder))
+	var documentService *documents.Service
+	if cfg.Documents.Enabled {
+		documentService = documents.NewService(cfg.Documents, pgStore.TenantStore(), documentsEmbedder)
+		documentService.SetOCR(gatewayService)
+		httpServer.SetDocuments(documentService)
+	}
+
+	// Embedder per feature: settings stored in the database override config.toml
+	embedderSettings := embedders.NewService(pgStore.TenantStore(), embedderDefaults(cfg.Embedder),
+		func(e domain.EmbedderSettings) (embedders.Client, error) {
+			return newCacheEmbeddingClient(embedderConfigFor(e)), nil
+		})
+	embedderSettings.Bind(domain.EmbedderFeatureSemanticCache, embedders.Binding{
+		Apply: func(e domain.EmbedderSettings) { embeddingClient.Set(newCacheEmbeddingClient(embedderConfigFor(e))) },
+	})
+	embedderSettings.Bind(domain.EmbedderFeatureToolSearch, embedders.Binding{
+		Apply: func(e domain.EmbedderSettings) { embedder.Set(newToolSearchEmbedder(embedderConfigFor(e))) },
+		Reset: func(ctx context.Context) {
+			indexed, err := mcpGateway.ReindexToolEmbeddings(ctx)
+			if err != nil {
+				slog.Error("Failed to re-embed MCP tools", "indexed", indexed, "error", err)
+				return
+			}
+			slog.Info("Re-embedded MCP tools for the new embedder", "tools", indexed)
+		},
+	})
+	embedderSettings.Bind(domain.EmbedderFeatureInjection, embedders.Binding{
+		Apply: func(e domain.EmbedderSettings) { injectionEmbedder.Set(newCacheEmbeddingClient(embedderConfigFor(e))) },
+		Reset: func(ctx context.Context) {
+			indexed, err := injectionService.Reindex(ctx)
+			if err != nil {
+				slog.Error("Failed to re-embed the injection corpus", "indexed", indexed, "error", err)
+				return
+			}
+			slog.Info("Re-embedded the injection corpus for the new embedder", "patterns", indexed)
+		},
+	})
+	embedderSettings.Bind(domain.EmbedderFeatureDocuments, embedders.Binding{
+		Apply: func(e domain.EmbedderSettings) { documentsEmbedder.Set(newCacheEmbeddingClient(embedderConfigFor(e))) },
+		Reset: func(ctx context.Context) {
+			if documentService == nil {
+				return
+			}
+			indexed, err := documentService.Reindex(ctx)
+			if err != nil {
+				slog.Error("Failed to re-embed document chunks", "indexed", indexed, "error", err)
+				return
+			}
+			slog.Info("Re-embedded document chunks for the new embedder", "chunks", indexed)
+		},
+	})
+	embedderSettings.Start(ctx)
+	httpServer.SetEmbedders(embedderSettings)
+
+	// Seed and embed the injection corpus once its embedder is installed
+	go func() {
+		if err := injectionService.Seed(ctx); err != nil {
+			slog.Warn("Failed to prepare the injection corpus, similarity detection finds nothing until it is embedded", "error", err)
+		}
+	}()
+
+	// Hot reload: each consumer swaps to the new config atomically
+	configHolder.OnReload("gateway", func(old, next *config.Config) {
+		providerManager.SetConfig(next)
+		gatewayService.SetConfig(next)
+		if !reflect.DeepEqual(old.Providers, next.Providers) || old.Warmup != next.Warmup {
+			go gatewayService.WarmProviders(ctx, "default")
+		}
+		responsesService.SetConfig(next)
+		httpServer.SetConfig(next)
+		dispatcher.Reconfigure(dispatcherConfigFor(next.Server))
+		configureStickyRouting(router, next.StickyRouting)
+	})
+	configHolder.OnReload("embedder", func(old, next *config.Config) {
+		if old.Embedder == next.Embedder {
+			return
+		}
+		if old.Embedder.Model != next.Embedder.Model {
+			slog.Warn("Embedding model changed; existing semantic cache and tool search embeddings may no longer match")
+		}
+		// Only features without their own embedder follow config.toml
+		embedderSettings.SetDefaults(embedderDefaults(next.Embedder))
+	})
+	configHolder.OnReload("telemetry", func(old, next *config.Config) {
+		logLevel.Set(parseLogLevel(next.Telemetry.LogLevel))
+		if old.Telemetry.OTLPEndpoint != next.Telemetry.OTLPEndpoint {
+			slog.Info("Telemetry endpoint changed", "otlp_endpoint", next.Telemetry.OTLPEndpoint)
+		}
+	})
+	httpServer.SetConfigHolder(configHolder)
+
+	// Feature flags gating new behaviors, managed via GraphQL
+	httpServer.SetFeatureFlags(featureflags.NewService(pgStore.TenantStore()))
+
+	// Kill switches stopping traffic to a provider or model during incidents
+	killSwitches := killswitch.NewService(pgStore.TenantStore())
+	killSwitches.Start(ctx)
+	router.SetKillSwitch(killSwitches)
+	gatewayService.SetKillSwitch(killSwitches)
+	httpServer.SetKillSwitch(killSwitches)
+
+	// Experiments splitting traffic across model and system-prompt variants
+	experimentService := experiments.NewService(pgStore.TenantStore())
+	experimentService.Start(ctx)
+	gatewayService.SetExperiments(experimentService)
+	httpServer.SetExperiments(experimentService)
+
+	// Data-plane audit events, turned on per tenant via GraphQL
+	dataAudit := dataaudit.NewService(cfg.DataAudit, pgStore.TenantStore())
+	dataAudit.Start(ctx)
+	httpServer.SetDataAudit(dataAudit)
+
+	// Signed response attestations; keys are sealed by the store's keyring
+	attestations := attestation.NewService(cfg.Attestation, pgStore.TenantStore())
+	attestations.Start(ctx)
+	httpServer.SetAttestation(attestations)
+
+	// Provider connections opened ahead of the first request and kept open
+	go gatewayService.WarmProviders(ctx, "default")
+	providerManager.StartKeepAlive(ctx)
+
+	// Usage, policy violation and audit events streamed to Kafka or NATS
+	// through the event outbox. Built even when disabled, to drop the triggers.
+	eventStream, err := eventstream.NewService(cfg.EventStream, pgStore.TenantStore())
+	if err != nil {
+		slog.Error("Failed to initialize event streaming", "error", err)
+		os.Exit(1)
+	}
+	eventStream.Start(ctx)
+
+	// Model pricing: bundled catalog, provider-reported prices and admin overrides
+	pricingService := pricing.NewService(pgStore.TenantStore(), func(ctx context.Context) ([]domain.ModelInfo, error) {
+		providers, err := pgStore.ListProviderConfigs(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var models []domain.ModelInfo
+		for _, p := range providers {
+			if !p.Enabled {
+				continue
+			}
+			providerModels, err := gatewayService.ListProviderModels(ctx, "default", p.Provider, p)
+			if err != nil {
+				slog.Debug("Skipping provider prices", "provider", p.Provider, "error", err)
+				continue
+			}
+			models = append(models, providerModels...)
+		}
+		return models, nil
+	})
+	gatewayService.SetPricing(pricingService)
+	httpServer.SetPricing(pricingService)
+	pricingService.StartRefresher(ctx, cfg.Pricing.RefreshInterval)
+
+	// Model discovery: keeps available_models current with every enabled provider
+	if cfg.Discovery.Enabled {
+		discovery.NewService(cfg.Discovery, pgStore.TenantStore(), gatewayService, audit.NewService(pgStore)).Start(ctx)
+	}
+
+	// Files API and resumable uploads (single-tenant mode uses the default tenant store)
+	if fileStore, err := pgStore.GetTenantStore("default"); err == nil {
+		fileService := files.NewService(cfg.Files, fileStore)
+		if cfg.Files.Backend == "object_store" {
+			objects, err := objectstore.New(ctx, cfg.Files.ObjectStore)
+			if err != nil {
+				slog.Error("Failed to create files object store", "error", err)
+				os.Exit(1)
+			}
+			fileService.SetObjectStore(objects)
+		}
+		fileService.SetClients(gatewayService)
+		fileService.StartJanitor(ctx)
+		httpServer.SetFileService(fileService)
+
+		batchService := batch.NewService(cfg, fileStore, gatewayService, fileService, pgStore.UsageRepository())
+		batchService.StartPoller(ctx)
+		configHolder.OnReload("batches", func(_, next *config.Config) {
+			batchService.SetConfig(next)
+		})
+		httpServer.SetBatchService(batchService)
+
+		// Fine-tuning jobs at providers, with training files from the files API
+		fineTuneService := finetune.NewService(cfg, fileStore, gatewayService, fileService)
+		fineTuneService.StartPoller(ctx)
+		configHolder.OnReload("fine_tuning", func(_, next *config.Config) {
+			fineTuneService.SetConfig(next)
+		})
+		httpServer.SetFineTuneService(fineTuneService)
+	} else {
+		slog.Warn("File uploads disabled", "error", err)
+	}
+
+	// Stored conversation threads for /v1/threads and thread_id on chat completions
+	if threadStore, err := pgStore.GetTenantStore("default"); err == nil {
+		threadService := threads.NewService(cfg.Threads, threadStore)
+		threadService.StartJanitor(ctx)
+		httpServer.SetThreadService(threadService)
+		// Assistants API subset: runs execute on threads through the chat pipeline
+		httpServer.SetAssistants(assistants.NewService(threadStore, threadService))
+	} else {
+		slog.Warn("Threads disabled", "error", err)
+	}
+
+	// Idempotency-Key support on chat completions and responses
+	if idempotencyStore, err := pgStore.GetTenantStore("default"); err == nil {
+		idempotencyService := idempotency.NewService(cfg.Idempotency, idempotencyStore)
+		idempotencyService.StartJanitor(ctx)
+		configHolder.OnReload("idempotency", func(_, next *config.Config) {
+			idempotencyService.SetConfig(next.Idempotency)
+		})
+		httpServer.SetIdempotency(idempotencyService)
+	} else {
+		slog.Warn("Idempotency keys disabled", "error", err)
+	}
+
+	// SIGHUP reloads config.toml without dropping in-flight requests
+	// (registered once every reload hook is in place)
+	hupChan := make(chan os.Signal, 1)
+	signal.Notify(hupChan, syscall.SIGHUP)
+	go func() {
+		for range hupChan {
+			slog.Info("Received SIGHUP, reloading configuration", "path", *configPath)
+			if _, err := configHolder.Reload(); err != nil {
+				slog.Error("Configuration reload failed, keeping current configuration", "error", err)
+			}
+		}
+	}()
+
+	// Retention janitor for usage and audit tables. Policies may be enabled per
+	// tenant via GraphQL, so it runs even when retention is disabled by default.
+	var archiveStore objectstore.Store
+	if cfg.Retention.Archive.Type != "" {
+		archiveStore, err = objectstore.New(ctx, cfg.Retention.Archive)
+		if err != nil {
+			slog.Warn("Retention archive disabled; tables that require archiving will not be pruned", "error", err)
+			archiveStore = nil
+		}
+	}
+	retention.NewService(cfg.Retention, pgStore.TenantStore(), archiveStore).StartJanitor(ctx)
+
+	// Audit log export (files go to the object store if one is configured)
+	var auditExportStore objectstore.Store
+	if cfg.AuditExport.Destination.Type != "" {
+		if auditExportStore, err = objectstore.New(ctx, cfg.AuditExport.Destination); err != nil {
+			slog.Warn("Audit export object store unavailable; exports are kept for download", "error", err)
+			auditExportStore = nil
+		}
+	}
+	httpServer.SetAuditExport(auditexport.NewService(cfg.AuditExport, pgStore.TenantStore(), auditExportStore))
+
+	// Billing periods (monthly quota rollover and usage snapshots)
+	quotaService := quota.NewService(pgStore.TenantStore())
+	quotaService.Start(ctx)
+	httpServer.SetQuota(quotaService)
+
+	// Usage digest emails (preferences can be saved without email configured)
+	var digestSender email.Sender
+	if cfg.Email.Enabled {
+		if smtpSender, err := email.NewSMTPSender(cfg.Email); err != nil {
+			slog.Warn("Email disabled", "error", err)
+		} else {
+			digestSender = smtpSender
+		}
+	}
+	digestService := digest.NewService(cfg.Email, pgStore.TenantStore(), digestSender)
+	if digestService.Enabled() {
+		digestService.Start(ctx)
+	}
+	httpServer.SetDigests(digestService)
+
+	// Month-end spend forecasts, with alerts for roles on track to exceed their budget
+	forecaster := analytics.NewForecaster(pgStore.TenantStore(), digestSender, metrics)
+	if cfg.Forecast.AlertsEnabled {
+		forecaster.Start(ctx, cfg.Forecast.CheckInterval)
+	}
+	httpServer.SetForecaster(forecaster)
+
+	// Self-service registration requests, reviewed by admins
+	registrations := registration.NewService(pgStore.TenantStore(), cfg.Registration, digestSender)
+	registrations.SetPasswordPolicy(passwordPolicy)
+	httpServer.SetRegistration(registrations)
+
+	// Two-factor codes at login, required of roles the tenant chooses
+	httpServer.SetTwoFactor(twofactor.NewService(cfg.TwoFactor, pgStore.TenantStore()))
+
+	// MCP tools a role calls without permission are queued for admin approval
+	toolApprovals := toolapproval.NewService(pgStore.TenantStore(), cfg.ToolApproval, digestSender)
+	mcpServer.SetToolApprovals(toolApprovals)
+	httpServer.SetToolApprovals(toolApprovals)
+
+	// Model sunset dates: deprecation headers, replacement rewrites and alerts
+	deprecations := deprecation.NewService(cfg.Deprecation, pgStore.TenantStore(), digestSender)
+	deprecations.Start(ctx)
+	httpServer.SetDeprecations(deprecations)
+
+	// Provider health samples, for the timeline and monthly SLA reports
+	healthHistory := healthhistory.NewService(cfg.HealthHistory, pgStore.TenantStore(), healthTracker)
+	healthHistory.Start(ctx)
+	httpServer.SetHealthHistory(healthHistory)
+
+	// Tenants served on their own hostnames, with per-domain certificates
+	if cfg.Domains.Enabled {
+		domains := customdomains.NewService(cfg.Domains, pgStore.TenantStore(), pgStore.TenantRepository())
+		domains.Start(ctx)
+		httpServer.SetCustomDomains(domains)
+	}
+
+	// Projects (per-team budgets and usage rollups)
+	httpServer.SetProjects(projects.NewService(pgStore.TenantStore()))
+
+	// Per-API-key spending caps, kept current with each request's cost
+	keyBudgets := keybudget.NewService(pgStore.TenantStore())
+	gatewayService.SetKeyBudgets(keyBudgets)
+	httpServer.SetKeyBudgets(keyBudgets)
+
+	// Per-conversation token and cost ceilings of role budgets
+	gatewayService.SetConversationBudgets(convbudget.NewService())
+
+	// Admin replay of captured requests
+	httpServer.SetReplay(replay.NewService(pgStore, gatewayService))
+
+	// Feedback on responses, exportable as fine-tuning data
+	httpServer.SetFeedback(feedback.NewService(pgStore.TenantStore()))
+
+	// Ollama model management (installed models are synced into available models)
+	httpServer.SetOllama(ollama.NewService(pgStore))
+
+	// Custom models on OpenAI-compatible endpoints (synced into available models)
+	httpServer.SetCustomModels(custommodels.NewService(pgStore.TenantStore()))
+	httpServer.SetSemanticCache(semanticCacheService)
+
+	// Readiness: /ready reports each dependency, and the router avoids
+	// providers without a usable key while the gateway is degraded. Missing
+	// provider keys only degrade the instance, so a fresh install still
+	// serves the admin UI where keys are added.
+	readinessChecker := readiness.NewChecker(5 * time.Second)
+	readinessChecker.Add(readiness.DependencyPostgres, true, readiness.Postgres(pgStore.DB().GetDB()))
+	readinessChecker.Add(readiness.DependencyProviders, false, readiness.Providers(pgStore.TenantStore(), keySelector))
+	readinessChecker.Add(readiness.DependencyDispatcher, true, readiness.Dispatcher(dispatcher))
+	if replicas := pgStore.DB().Replicas(); replicas != nil {
+		readinessChecker.Add(readiness.DependencyReplicas, false, readiness.Replicas(replicas.States))
+	}
+	readinessChecker.Add(readiness.DependencyEmbedder, false, readiness.Embedder(embeddingClient.Embed, func(ctx context.Context) bool {
+		enabled, err := pgStore.TenantStore().SemanticCacheEnabled(ctx)
+		return err != nil || enabled // Check the embedder when unsure
+	}))
+	go readinessChecker.Run(ctx, 15*time.Second)
+	router.SetReadiness(readinessChecker)
+	httpServer.SetReadiness(readinessChecker)
+
+	// Scheduled usage export to the data warehouse bucket
+	if cfg.Export.Enabled {
+		exportStore, err := objectstore.New(ctx, cfg.Export.Destination)
+		if err != nil {
+			slog.Warn("Usage export disabled", "error", err)
+		} else {
+			usageExporter := usageexport.NewService(cfg.Export, pgStore.TenantStore(), exportStore)
+			usageExporter.StartScheduler(ctx)
+			httpServer.SetUsageExport(usageExporter)
+		}
+	}
+	serverStopped := make(chan struct{})
 	go func() {
+		defer close(serverStopped)
 		slog.Info("Starting unified HTTP server",
 			"addr", httpAddr,
 			"endpoints", []string{"/v1/*", "/graphql", "/mcp", "/metrics"},
@@ -340,7 +777,7 @@ func main() {
 	<-ctx.Done()
 	slog.Info("Shutting down...")
 
-	// Give servers time to complete graceful shutdown
-	time.Sleep(2 * time.Second)
+	// The HTTP server returns once its remaining handlers finished
+	<-serverStopped
 	slog.Info("ModelGate stopped")
 }
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+
+	"modelgate/internal/cache/embedding"
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+	"modelgate/internal/gateway"
+	"modelgate/internal/mcp"
+	"modelgate/internal/routing"
+)
+
+// dispatcherConfigFor builds the dispatcher settings from the server config,
+// keeping the defaults for anything left unset
+func dispatcherConfigFor(server config.ServerConfig) gateway.DispatcherConfig {
+	dispatcherConfig := gateway.DefaultDispatcherConfig()
+	if server.MinWorkers > 0 {
+		dispatcherConfig.MinWorkers = server.MinWorkers
+	}
+	if server.MaxWorkers > 0 {
+		dispatcherConfig.MaxWorkers = server.MaxWorkers
+	}
+	if server.MaxQueuedRequests > 0 {
+		dispatcherConfig.MaxQueuedRequests = server.MaxQueuedRequests
+	}
+	if server.ScaleUpThreshold > 0 {
+		dispatcherConfig.ScaleUpThreshold = server.ScaleUpThreshold
+	}
+	if server.ScaleDownThreshold > 0 {
+		dispatcherConfig.ScaleDownThreshold = server.ScaleDownThreshold
+	}
+	dispatcherConfig.MaxConcurrentPerKey = int32(server.MaxConcurrentPerKey)
+	dispatcherConfig.MaxConcurrentPerRole = int32(server.MaxConcurrentPerRole)
+	dispatcherConfig.QueueOnConcurrencyLimit = server.QueueOnConcurrencyLimit
+	if server.FairnessKey != "" {
+		dispatcherConfig.FairnessKey = server.FairnessKey
+	}
+	if server.StarvationTimeout > 0 {
+		dispatcherConfig.StarvationTimeout = server.StarvationTimeout
+	}
+	return dispatcherConfig
+}
+
+// configureStickyRouting applies the conversation affinity settings to the router
+func configureStickyRouting(router *routing.Router, cfg config.StickyRoutingConfig) {
+	if !cfg.Enabled {
+		router.SetAffinity(0, 0)
+		return
+	}
+	router.SetAffinity(cfg.TTL, cfg.MaxConversations)
+}
+
+// newCacheEmbeddingClient creates the embedding client for the semantic cache.
+// Supports both Ollama (default) and OpenAI embedders.
+func newCacheEmbeddingClient(cfg config.EmbedderConfig) embedding.EmbeddingClient {
+	switch cfg.Type {
+	case "openai":
+		// A base URL without a key is an OpenAI-compatible self-hosted server
+		if cfg.APIKey == "" && cfg.BaseURL == "" {
+			slog.Warn("Semantic cache: OpenAI embedder configured but no API key provided")
+			return nil
+		}
+		slog.Info("Semantic cache: using OpenAI embeddings", "model", cfg.Model)
+		return newOpenAIEmbeddingAdapter(cfg.APIKey, cfg.BaseURL, cfg.Model)
+	case "ollama":
+		baseURL := cfg.BaseURL
+		if baseURL == "" {
+			baseURL = "http://localhost:11434"
+		}
+		model := cfg.Model
+		if model == "" {
+			model = "nomic-embed-text"
+		}
+		slog.Info("Semantic cache: using Ollama embeddings", "url", baseURL, "model", model)
+		return newOllamaEmbeddingAdapter(baseURL, model)
+	default:
+		// Default to Ollama with nomic-embed-text
+		slog.Info("Semantic cache: using default Ollama embeddings", "model", "nomic-embed-text")
+		return newOllamaEmbeddingAdapter("http://localhost:11434", "nomic-embed-text")
+	}
+}
+
+// newToolSearchEmbedder creates the embedder for semantic MCP tool search
+func newToolSearchEmbedder(cfg config.EmbedderConfig) mcp.Embedder {
+	switch cfg.Type {
+	case "openai":
+		slog.Info("Using OpenAI embedder", "model", cfg.Model)
+		if cfg.BaseURL != "" {
+			return mcp.NewOpenAIEmbedderWithBaseURL(cfg.APIKey, cfg.BaseURL, cfg.Model)
+		}
+		return mcp.NewOpenAIEmbedder(cfg.APIKey)
+	case "ollama":
+		slog.Info("Using Ollama embedder", "url", cfg.BaseURL, "model", cfg.Model)
+		return mcp.NewOllamaEmbedder(cfg.BaseURL, cfg.Model)
+	default:
+		// Default to Ollama with nomic-embed-text
+		slog.Info("Using default Ollama embedder", "model", "nomic-embed-text")
+		return mcp.NewOllamaEmbedder("http://localhost:11434", "nomic-embed-text")
+	}
+}
+
+// openAIEmbeddingsURL is the base URL of OpenAI embedders without one
+const openAIEmbeddingsURL = "https://api.openai.com/v1"
+
+// embedderDefaults is the embedder config.toml gives features without stored settings
+func embedderDefaults(cfg config.EmbedderConfig) domain.EmbedderSettings {
+	return domain.EmbedderSettings{
+		Type:       cfg.Type,
+		Model:      cfg.Model,
+		BaseURL:    cfg.BaseURL,
+		APIKey:     cfg.APIKey,
+		FromConfig: true,
+	}
+}
+
+// embedderConfigFor is the config for a feature's embedder settings
+func embedderConfigFor(e domain.EmbedderSettings) config.EmbedderConfig {
+	return config.EmbedderConfig{Type: e.Type, APIKey: e.APIKey, BaseURL: e.BaseURL, Model: e.Model}
+}
+
+var errEmbedderNotConfigured = errors.New("embedding client not configured")
+
+// swappableEmbeddingClient lets the semantic cache switch embedders on reload.
+// Calls in flight finish on the client they started with.
+type swappableEmbeddingClient struct {
+	current atomic.Pointer[embeddingClientBox]
+}
+
+type embeddingClientBox struct{ client embedding.EmbeddingClient }
+
+func newSwappableEmbeddingClient(client embedding.EmbeddingClient) *swappableEmbeddingClient {
+	s := &swappableEmbeddingClient{}
+	s.Set(client)
+	return s
+}
+
+func (s *swappableEmbeddingClient) Set(client embedding.EmbeddingClient) {
+	s.current.Store(&embeddingClientBox{client: client})
+}
+
+func (s *swappableEmbeddingClient) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	client := s.current.Load().client
+	if client == nil {
+		return nil, errEmbedderNotConfigured
+	}
+	return client.Embed(ctx, texts)
+}
+
+// swappableEmbedder does the same for the MCP tool search embedder
+type swappableEmbedder struct {
+	current atomic.Pointer[embedderBox]
+}
+
+type embedderBox struct{ embedder mcp.Embedder }
+
+func newSwappableEmbedder(embedder mcp.Embedder) *swappableEmbedder {
+	s := &swappableEmbedder{}
+	s.Set(embedder)
+	return s
+}
+
+func (s *swappableEmbedder) Set(embedder mcp.Embedder) {
+	s.current.Store(&embedderBox{embedder: embedder})
+}
+
+func (s *swappableEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	return s.current.Load().embedder.Embed(ctx, text)
+}
+
+func (s *swappableEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	return s.current.Load().embedder.EmbedBatch(ctx, texts)
+}
+
+// parseLogLevel maps telemetry.log_level to a slog level (info when unset)
+func parseLogLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
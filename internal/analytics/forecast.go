@@ -0,0 +1,427 @@
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"modelgate/internal/domain"
+	"modelgate/internal/email"
+	"modelgate/internal/telemetry"
+)
+
+// burnWindow is how far back spend is averaged for the burn rate, so a
+// forecast follows recent changes in traffic rather than the whole month
+const burnWindow = 7 * 24 * time.Hour
+
+// ForecastStore is the persistence used by the forecaster (implemented by postgres.TenantStore)
+type ForecastStore interface {
+	GetDailyCostByAPIKey(ctx context.Context, start, end time.Time) ([]*domain.APIKeyDailyCost, error)
+	GetRole(ctx context.Context, id string) (*domain.Role, error)
+	GetGroupRoles(ctx context.Context, groupID string) ([]*domain.Role, error)
+}
+
+// Forecaster projects month-end spend per API key and per role, and alerts
+// when a role, or a key with a budget of its own, is forecast to exceed its
+// monthly budget before it actually does
+type Forecaster struct {
+	store      ForecastStore
+	sender     email.Sender       // nil when email is disabled
+	metrics    *telemetry.Metrics // May be nil
+	httpClient *http.Client
+	now        func() time.Time
+
+	mu      sync.Mutex
+	alerted map[string]time.Time // Scope and ID -> start of the month it was last alerted for
+}
+
+// NewForecaster creates a forecaster. Without a sender, alerts go only to the
+// webhook and Slack channels of the role's budget policy.
+func NewForecaster(store ForecastStore, sender email.Sender, metrics *telemetry.Metrics) *Forecaster {
+	return &Forecaster{
+		store:      store,
+		sender:     sender,
+		metrics:    metrics,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		now:        time.Now,
+		alerted:    make(map[string]time.Time),
+	}
+}
+
+// Forecast projects the current month's (UTC) spend of every API key and
+// role with usage this month. A key's spend counts toward its role and each
+// role of its group, as their budgets are all enforced on it.
+func (f *Forecaster) Forecast(ctx context.Context) (*domain.SpendForecastReport, error) {
+	report, _, err := f.forecast(ctx)
+	return report, err
+}
+
+// forecast builds the report and returns the own budgets of the keys in it
+func (f *Forecaster) forecast(ctx context.Context) (*domain.SpendForecastReport, map[string]*domain.APIKeyBudget, error) {
+	now := f.now().UTC()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	monthEnd := monthStart.AddDate(0, 1, 0)
+
+	costs, err := f.store.GetDailyCostByAPIKey(ctx, monthStart, now)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get daily cost: %w", err)
+	}
+
+	keys := make(map[string]*series)
+	roles := make(map[string]*series)
+	keyRoles := make(map[string][]string) // API key ID -> role IDs
+	keyBudgets := make(map[string]*domain.APIKeyBudget)
+	groupRoles := make(map[string][]string)
+	for _, c := range costs {
+		k := keys[c.APIKeyID]
+		if k == nil {
+			roleIDs, err := f.effectiveRoles(ctx, c, groupRoles)
+			if err != nil {
+				return nil, nil, err
+			}
+			k = &series{forecast: domain.SpendForecast{Scope: domain.ForecastScopeAPIKey, ID: c.APIKeyID, Name: c.APIKeyName, RoleID: c.RoleID}}
+			if k.forecast.RoleID == "" && len(roleIDs) > 0 {
+				k.forecast.RoleID = roleIDs[0]
+			}
+			keys[c.APIKeyID] = k
+			keyRoles[c.APIKeyID] = roleIDs
+			if c.Budget != nil {
+				keyBudgets[c.APIKeyID] = c.Budget
+			}
+		}
+		k.add(c.Day, c.CostUSD)
+		for _, roleID := range keyRoles[c.APIKeyID] {
+			r := roles[roleID]
+			if r == nil {
+				r = &series{forecast: domain.SpendForecast{Scope: domain.ForecastScopeRole, ID: roleID}}
+				roles[roleID] = r
+			}
+			r.add(c.Day, c.CostUSD)
+		}
+	}
+
+	report := &domain.SpendForecastReport{PeriodStart: monthStart, PeriodEnd: monthEnd, GeneratedAt: now}
+	budgets := make(map[string]float64, len(roles))
+	for id, r := range roles {
+		role, err := f.store.GetRole(ctx, id)
+		if err != nil {
+			return nil, nil, fmt.Errorf("get role: %w", err)
+		}
+		if role == nil {
+			continue
+		}
+		r.forecast.Name = role.Name
+		if role.Policy != nil && role.Policy.BudgetPolicy.Enabled {
+			budgets[id] = role.Policy.BudgetPolicy.MonthlyLimitUSD
+		}
+		report.Roles = append(report.Roles, r.project(budgets[id], monthStart, now, monthEnd))
+	}
+	for id, k := range keys {
+		// A key with a monthly limit of its own is shown against it, other
+		// keys against their role's budget
+		budget := monthlyLimit(keyBudgets[id])
+		if budget == 0 {
+			budget = budgets[k.forecast.RoleID]
+		}
+		report.APIKeys = append(report.APIKeys, k.project(budget, monthStart, now, monthEnd))
+	}
+	sortForecasts(report.APIKeys)
+	sortForecasts(report.Roles)
+	return report, keyBudgets, nil
+}
+
+// monthlyLimit is the monthly limit of an API key budget: the hard limit, or
+// the soft limit when only that is set. 0 when there is none.
+func monthlyLimit(b *domain.APIKeyBudget) float64 {
+	if b == nil {
+		return 0
+	}
+	if b.MonthlyHardLimitUSD > 0 {
+		return b.MonthlyHardLimitUSD
+	}
+	return b.MonthlySoftLimitUSD
+}
+
+// effectiveRoles returns the IDs of a key's role and its group's roles, the
+// roles whose policies are enforced on its requests. Group roles are looked
+// up once per group.
+func (f *Forecaster) effectiveRoles(ctx context.Context, c *domain.APIKeyDailyCost, groupRoles map[string][]string) ([]string, error) {
+	var roleIDs []string
+	if c.RoleID != "" {
+		roleIDs = append(roleIDs, c.RoleID)
+	}
+	if c.GroupID == "" {
+		return roleIDs, nil
+	}
+	ids, ok := groupRoles[c.GroupID]
+	if !ok {
+		roles, err := f.store.GetGroupRoles(ctx, c.GroupID)
+		if err != nil {
+			return nil, fmt.Errorf("get group roles: %w", err)
+		}
+		for _, role := range roles {
+			ids = append(ids, role.ID)
+		}
+		groupRoles[c.GroupID] = ids
+	}
+	for _, id := range ids {
+		if !slices.Contains(roleIDs, id) {
+			roleIDs = append(roleIDs, id)
+		}
+	}
+	return roleIDs, nil
+}
+
+// series accumulates a key's or role's cost per day
+type series struct {
+	forecast domain.SpendForecast
+	days     map[time.Time]float64
+}
+
+func (s *series) add(day time.Time, cost float64) {
+	if s.days == nil {
+		s.days = make(map[time.Time]float64)
+	}
+	s.days[day] += cost
+	s.forecast.SpentUSD += cost
+}
+
+// project fills in the forecast from the daily costs as of now
+func (s *series) project(budget float64, monthStart, now, monthEnd time.Time) *domain.SpendForecast {
+	fc := s.forecast
+	fc.BudgetUSD = budget
+	fc.DailyBurnUSD = burnRate(s.days, monthStart, now)
+	project(&fc, now, monthEnd)
+	return &fc
+}
+
+// burnRate averages the spend per day over the trailing burn window, or
+// since monthStart early in the month. Days are UTC day starts.
+func burnRate(days map[time.Time]float64, monthStart, now time.Time) float64 {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	windowStart := today.Add(-burnWindow).AddDate(0, 0, 1)
+	if windowStart.Before(monthStart) {
+		windowStart = monthStart
+	}
+	elapsedDays := now.Sub(windowStart).Hours() / 24
+	if elapsedDays <= 0 {
+		return 0
+	}
+	var spent float64
+	for day, cost := range days {
+		if !day.Before(windowStart) {
+			spent += cost
+		}
+	}
+	return spent / elapsedDays
+}
+
+// project extends the spend to monthEnd at the burn rate and compares it
+// with the budget
+func project(fc *domain.SpendForecast, now, monthEnd time.Time) {
+	remainingDays := monthEnd.Sub(now).Hours() / 24
+	fc.ProjectedUSD = fc.SpentUSD + fc.DailyBurnUSD*remainingDays
+	if fc.BudgetUSD <= 0 {
+		return
+	}
+	fc.OverBudget = fc.ProjectedUSD > fc.BudgetUSD
+	switch {
+	case fc.SpentUSD >= fc.BudgetUSD:
+		at := now
+		fc.ExhaustedAt = &at
+	case fc.OverBudget && fc.DailyBurnUSD > 0:
+		days := (fc.BudgetUSD - fc.SpentUSD) / fc.DailyBurnUSD
+		at := now.Add(time.Duration(days * 24 * float64(time.Hour)))
+		fc.ExhaustedAt = &at
+	}
+}
+
+func sortForecasts(fcs []*domain.SpendForecast) {
+	sort.Slice(fcs, func(i, j int) bool {
+		if fcs[i].ProjectedUSD != fcs[j].ProjectedUSD {
+			return fcs[i].ProjectedUSD > fcs[j].ProjectedUSD
+		}
+		return fcs[i].ID < fcs[j].ID
+	})
+}
+
+// CheckAlerts alerts once a month for each role, and each key with a monthly
+// limit of its own, forecast to exceed its monthly budget while its actual
+// spend is still under it (exceeding the budget is alerted by budget
+// enforcement). Key alerts go to the key budget's webhook. It returns how
+// many alerts were sent.
+func (f *Forecaster) CheckAlerts(ctx context.Context) (int, error) {
+	report, keyBudgets, err := f.forecast(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, fc := range report.Roles {
+		if !f.shouldAlert(fc, report.PeriodStart) {
+			continue
+		}
+		role, err := f.store.GetRole(ctx, fc.ID)
+		if err != nil {
+			return sent, fmt.Errorf("get role: %w", err)
+		}
+		if role == nil || role.Policy == nil {
+			continue
+		}
+		slog.Warn("Role is forecast to exceed its monthly budget",
+			"role", fc.Name, "spent_usd", fc.SpentUSD, "projected_usd", fc.ProjectedUSD, "budget_usd", fc.BudgetUSD)
+		// An alert no channel received is sent again on the next check
+		if err := f.notify(ctx, role.Policy.BudgetPolicy, fc, report.PeriodEnd); err != nil {
+			slog.Error("Failed to send budget forecast alert", "role", fc.Name, "error", err)
+			continue
+		}
+		f.markAlerted(fc, report.PeriodStart)
+		sent++
+	}
+	for _, fc := range report.APIKeys {
+		budget := keyBudgets[fc.ID]
+		if monthlyLimit(budget) == 0 || !f.shouldAlert(fc, report.PeriodStart) {
+			continue
+		}
+		// Key budgets alert only through their own webhook
+		slog.Warn("API key is forecast to exceed its monthly budget",
+			"api_key", fc.Name, "spent_usd", fc.SpentUSD, "projected_usd", fc.ProjectedUSD, "budget_usd", fc.BudgetUSD)
+		if err := f.notify(ctx, domain.BudgetPolicy{AlertWebhook: budget.AlertWebhook}, fc, report.PeriodEnd); err != nil {
+			slog.Error("Failed to send budget forecast alert", "api_key", fc.Name, "error", err)
+			continue
+		}
+		f.markAlerted(fc, report.PeriodStart)
+		sent++
+	}
+	return sent, nil
+}
+
+// shouldAlert reports whether a forecast exceeds its budget before the spend
+// does, and hasn't been alerted for the month yet
+func (f *Forecaster) shouldAlert(fc *domain.SpendForecast, month time.Time) bool {
+	if !fc.OverBudget || fc.SpentUSD >= fc.BudgetUSD {
+		return false
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	last, ok := f.alerted[fc.Scope+":"+fc.ID]
+	return !ok || last.Before(month)
+}
+
+// markAlerted records that a forecast has been alerted for the month
+func (f *Forecaster) markAlerted(fc *domain.SpendForecast, month time.Time) {
+	f.mu.Lock()
+	f.alerted[fc.Scope+":"+fc.ID] = month
+	f.mu.Unlock()
+	if f.metrics != nil {
+		f.metrics.RecordSpendForecastAlert(fc.Scope)
+	}
+}
+
+// forecastAlert is the payload posted to a budget policy's alert webhook
+type forecastAlert struct {
+	Type string `json:"type"` // Always "budget_forecast"
+	*domain.SpendForecast
+	PeriodEnd time.Time `json:"period_end"`
+}
+
+// notify sends a forecast alert to the channels of a budget policy. Failures
+// are logged; an error is returned only when no channel received the alert.
+func (f *Forecaster) notify(ctx context.Context, policy domain.BudgetPolicy, fc *domain.SpendForecast, periodEnd time.Time) error {
+	subject := "Role"
+	if fc.Scope == domain.ForecastScopeAPIKey {
+		subject = "API key"
+	}
+	summary := fmt.Sprintf("%s %q is forecast to spend $%.2f this month, over its $%.2f budget ($%.2f spent so far)",
+		subject, fc.Name, fc.ProjectedUSD, fc.BudgetUSD, fc.SpentUSD)
+	if fc.ExhaustedAt != nil {
+		summary += fmt.Sprintf(". At $%.2f/day the budget runs out around %s.", fc.DailyBurnUSD, fc.ExhaustedAt.Format(time.DateOnly))
+	}
+
+	var attempted, failed int
+	if policy.AlertWebhook != "" {
+		attempted++
+		if err := f.post(ctx, policy.AlertWebhook, forecastAlert{Type: "budget_forecast", SpendForecast: fc, PeriodEnd: periodEnd}); err != nil {
+			slog.Error("Failed to send budget forecast webhook", "scope", fc.Scope, "name", fc.Name, "error", err)
+			failed++
+		}
+	}
+	if policy.AlertSlack != "" {
+		attempted++
+		if err := f.post(ctx, policy.AlertSlack, map[string]string{"text": summary}); err != nil {
+			slog.Error("Failed to send budget forecast to Slack", "scope", fc.Scope, "name", fc.Name, "error", err)
+			failed++
+		}
+	}
+	if f.sender != nil && len(policy.AlertEmails) > 0 {
+		attempted++
+		msg := &email.Message{
+			To:      policy.AlertEmails,
+			Subject: fmt.Sprintf("Budget forecast: %s is on track to exceed its monthly budget", fc.Name),
+			Text:    summary,
+		}
+		if err := f.sender.Send(ctx, msg); err != nil {
+			slog.Error("Failed to email budget forecast", "scope", fc.Scope, "name", fc.Name, "error", err)
+			failed++
+		}
+	}
+	if attempted > 0 && failed == attempted {
+		return fmt.Errorf("no alert channel of %s %q was reached", strings.ToLower(subject), fc.Name)
+	}
+	return nil
+}
+
+func (f *Forecaster) post(ctx context.Context, url string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := f.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// Start checks forecasts every interval until ctx is done
+func (f *Forecaster) Start(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	run := func() {
+		if _, err := f.CheckAlerts(ctx); err != nil {
+			slog.Error("Budget forecast check failed", "error", err)
+		}
+	}
+
+	go func() {
+		run()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				run()
+			}
+		}
+	}()
+}
@@ -0,0 +1,237 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+type fakeForecastStore struct {
+	costs   []*domain.APIKeyDailyCost
+	roles   map[string]*domain.Role
+	groups  map[string][]string // Group ID -> role IDs
+	lookups int
+	failAt  int // GetRole fails on this lookup (1-based), 0 never
+}
+
+func (f *fakeForecastStore) GetDailyCostByAPIKey(ctx context.Context, start, end time.Time) ([]*domain.APIKeyDailyCost, error) {
+	return f.costs, nil
+}
+
+func (f *fakeForecastStore) GetRole(ctx context.Context, id string) (*domain.Role, error) {
+	f.lookups++
+	if f.lookups == f.failAt {
+		return nil, errors.New("connection reset")
+	}
+	return f.roles[id], nil
+}
+
+func (f *fakeForecastStore) GetGroupRoles(ctx context.Context, groupID string) ([]*domain.Role, error) {
+	var roles []*domain.Role
+	for _, id := range f.groups[groupID] {
+		roles = append(roles, f.roles[id])
+	}
+	return roles, nil
+}
+
+func TestForecast(t *testing.T) {
+	var alerts []forecastAlert
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alert forecastAlert
+		if err := json.NewDecoder(r.Body).Decode(&alert); err != nil {
+			t.Errorf("decode alert: %v", err)
+		}
+		alerts = append(alerts, alert)
+	}))
+	defer webhook.Close()
+
+	day := func(d int) time.Time { return time.Date(2026, 6, d, 0, 0, 0, 0, time.UTC) }
+	store := &fakeForecastStore{roles: map[string]*domain.Role{
+		"r1": {ID: "r1", Name: "Support", Policy: &domain.RolePolicy{BudgetPolicy: domain.BudgetPolicy{
+			Enabled: true, MonthlyLimitUSD: 300, AlertWebhook: webhook.URL,
+		}}},
+	}}
+	// A quiet first week, then $20/day across two keys of the role
+	store.costs = append(store.costs, &domain.APIKeyDailyCost{APIKeyID: "k1", APIKeyName: "bot", RoleID: "r1", Day: day(1), CostUSD: 1})
+	for d := 3; d <= 10; d++ {
+		store.costs = append(store.costs,
+			&domain.APIKeyDailyCost{APIKeyID: "k1", APIKeyName: "bot", RoleID: "r1", Day: day(d), CostUSD: 15},
+			&domain.APIKeyDailyCost{APIKeyID: "k2", APIKeyName: "ci", RoleID: "r1", Day: day(d), CostUSD: 5})
+	}
+
+	f := NewForecaster(store, nil, nil)
+	f.now = func() time.Time { return day(11) }
+	report, err := f.Forecast(context.Background())
+	if err != nil {
+		t.Fatalf("Forecast failed: %v", err)
+	}
+	if len(report.Roles) != 1 || len(report.APIKeys) != 2 || report.APIKeys[0].ID != "k1" {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	role := report.Roles[0]
+	// $161 spent, $20/day over the trailing 7 days, 20 days to go
+	if role.SpentUSD != 161 || role.DailyBurnUSD != 20 || math.Abs(role.ProjectedUSD-561) > 1e-9 || !role.OverBudget {
+		t.Fatalf("unexpected role forecast %+v", role)
+	}
+	if role.ExhaustedAt == nil || !role.ExhaustedAt.Equal(day(11).Add(time.Duration(6.95*24*float64(time.Hour)))) {
+		t.Fatalf("unexpected exhaustion time %v", role.ExhaustedAt)
+	}
+	if k := report.APIKeys[0]; k.BudgetUSD != 300 || k.RoleID != "r1" {
+		t.Fatalf("expected keys without a budget of their own to be shown against their role's, got %+v", k)
+	}
+
+	// Alerted once for the month, before actual spend reaches the budget
+	for range 2 {
+		if _, err := f.CheckAlerts(context.Background()); err != nil {
+			t.Fatalf("CheckAlerts failed: %v", err)
+		}
+	}
+	if len(alerts) != 1 || alerts[0].Type != "budget_forecast" || alerts[0].ID != "r1" {
+		t.Fatalf("expected one forecast alert, got %+v", alerts)
+	}
+}
+
+func TestForecastCountsGroupRoles(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 6, d, 0, 0, 0, 0, time.UTC) }
+	store := &fakeForecastStore{
+		roles: map[string]*domain.Role{
+			"r1": {ID: "r1", Name: "Support"},
+			"r2": {ID: "r2", Name: "Research", Policy: &domain.RolePolicy{BudgetPolicy: domain.BudgetPolicy{Enabled: true, MonthlyLimitUSD: 100}}},
+		},
+		groups: map[string][]string{"g1": {"r1", "r2"}},
+		costs: []*domain.APIKeyDailyCost{
+			{APIKeyID: "k1", APIKeyName: "direct", RoleID: "r1", Day: day(1), CostUSD: 10},
+			// Gets both roles through its group, and r1 only once with its own role
+			{APIKeyID: "k2", APIKeyName: "grouped", GroupID: "g1", Day: day(1), CostUSD: 4},
+			{APIKeyID: "k3", APIKeyName: "both", RoleID: "r1", GroupID: "g1", Day: day(2), CostUSD: 2},
+		},
+	}
+	f := NewForecaster(store, nil, nil)
+	f.now = func() time.Time { return day(3) }
+	report, err := f.Forecast(context.Background())
+	if err != nil {
+		t.Fatalf("Forecast failed: %v", err)
+	}
+
+	spent := make(map[string]float64)
+	for _, fc := range report.Roles {
+		spent[fc.ID] = fc.SpentUSD
+	}
+	if spent["r1"] != 16 || spent["r2"] != 6 {
+		t.Fatalf("expected group keys' spend to count toward every role of the group, got %v", spent)
+	}
+	for _, k := range report.APIKeys {
+		if k.ID == "k2" && k.RoleID != "r1" {
+			t.Fatalf("expected a key without a role to be shown with its group's first role, got %+v", k)
+		}
+	}
+}
+
+func TestForecastAlertRetried(t *testing.T) {
+	var alerts int
+	failing := true
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		alerts++
+	}))
+	defer webhook.Close()
+
+	day := func(d int) time.Time { return time.Date(2026, 6, d, 0, 0, 0, 0, time.UTC) }
+	store := &fakeForecastStore{roles: map[string]*domain.Role{
+		"r1": {ID: "r1", Name: "Support", Policy: &domain.RolePolicy{BudgetPolicy: domain.BudgetPolicy{
+			Enabled: true, MonthlyLimitUSD: 100, AlertWebhook: webhook.URL,
+		}}},
+	}}
+	for d := 1; d <= 10; d++ {
+		store.costs = append(store.costs, &domain.APIKeyDailyCost{APIKeyID: "k1", RoleID: "r1", Day: day(d), CostUSD: 5})
+	}
+	f := NewForecaster(store, nil, nil)
+	f.now = func() time.Time { return day(11) }
+	ctx := context.Background()
+
+	// A failed send is not counted as the month's alert
+	if sent, err := f.CheckAlerts(ctx); err != nil || sent != 0 {
+		t.Fatalf("expected no alert while the webhook fails, got %d, %v", sent, err)
+	}
+	failing = false
+	if sent, err := f.CheckAlerts(ctx); err != nil || sent != 1 || alerts != 1 {
+		t.Fatalf("expected the alert to be sent on the next check, got %d (%d received), %v", sent, alerts, err)
+	}
+	if sent, _ := f.CheckAlerts(ctx); sent != 0 || alerts != 1 {
+		t.Fatalf("expected one alert for the month, got %d", alerts)
+	}
+
+	// Neither is a check that fails to load the role it alerts for, after
+	// the forecast loaded it
+	f = NewForecaster(store, nil, nil)
+	f.now = func() time.Time { return day(11) }
+	store.lookups, store.failAt = 0, 2
+	if _, err := f.CheckAlerts(ctx); err == nil {
+		t.Fatal("expected the role lookup error")
+	}
+	if sent, err := f.CheckAlerts(ctx); err != nil || sent != 1 || alerts != 2 {
+		t.Fatalf("expected the alert once the role loads, got %d, %v", sent, err)
+	}
+}
+
+func TestForecastKeyBudget(t *testing.T) {
+	var alerts []forecastAlert
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alert forecastAlert
+		if err := json.NewDecoder(r.Body).Decode(&alert); err != nil {
+			t.Errorf("decode alert: %v", err)
+		}
+		alerts = append(alerts, alert)
+	}))
+	defer webhook.Close()
+
+	day := func(d int) time.Time { return time.Date(2026, 6, d, 0, 0, 0, 0, time.UTC) }
+	store := &fakeForecastStore{roles: map[string]*domain.Role{
+		"r1": {ID: "r1", Name: "Support", Policy: &domain.RolePolicy{BudgetPolicy: domain.BudgetPolicy{Enabled: true, MonthlyLimitUSD: 1000}}},
+	}}
+	hard := &domain.APIKeyBudget{MonthlySoftLimitUSD: 50, MonthlyHardLimitUSD: 100, AlertWebhook: webhook.URL}
+	soft := &domain.APIKeyBudget{MonthlySoftLimitUSD: 500, AlertWebhook: webhook.URL}
+	daily := &domain.APIKeyBudget{DailyHardLimitUSD: 10}
+	for d := 1; d <= 10; d++ {
+		store.costs = append(store.costs,
+			&domain.APIKeyDailyCost{APIKeyID: "k1", APIKeyName: "hard", RoleID: "r1", Day: day(d), CostUSD: 5, Budget: hard},
+			&domain.APIKeyDailyCost{APIKeyID: "k2", APIKeyName: "soft", RoleID: "r1", Day: day(d), CostUSD: 5, Budget: soft},
+			&domain.APIKeyDailyCost{APIKeyID: "k3", APIKeyName: "daily", RoleID: "r1", Day: day(d), CostUSD: 5, Budget: daily})
+	}
+	f := NewForecaster(store, nil, nil)
+	f.now = func() time.Time { return day(11) }
+	ctx := context.Background()
+
+	report, err := f.Forecast(ctx)
+	if err != nil {
+		t.Fatalf("Forecast failed: %v", err)
+	}
+	budgets := make(map[string]float64)
+	for _, k := range report.APIKeys {
+		budgets[k.ID] = k.BudgetUSD
+	}
+	// The hard limit over the soft one, and the role's budget without a monthly limit
+	if budgets["k1"] != 100 || budgets["k2"] != 500 || budgets["k3"] != 1000 {
+		t.Fatalf("unexpected key budgets %v", budgets)
+	}
+
+	// Only the key forecast over its own limit is alerted, once for the month
+	for range 2 {
+		if _, err := f.CheckAlerts(ctx); err != nil {
+			t.Fatalf("CheckAlerts failed: %v", err)
+		}
+	}
+	if len(alerts) != 1 || alerts[0].Scope != domain.ForecastScopeAPIKey || alerts[0].ID != "k1" {
+		t.Fatalf("expected one alert for the key over its own budget, got %+v", alerts)
+	}
+}
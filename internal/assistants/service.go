@@ -0,0 +1,596 @@
+// Package assistants implements a subset of the OpenAI Assistants API on top
+// of stored threads: assistants, and runs that execute an assistant on a
+// thread through the gateway's chat pipeline. MCP tools the caller can see are
+// offered to the model as functions and run by the gateway; other function
+// calls are handed back to the client, as the Assistants API does.
+package assistants
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"modelgate/internal/domain"
+)
+
+// Errors returned by the service. HTTP handlers map these to status codes.
+var (
+	ErrAssistantNotFound = errors.New("assistant not found")
+	ErrRunNotFound       = errors.New("run not found")
+	ErrInvalidRequest    = errors.New("invalid request")
+	ErrRunActive         = errors.New("thread already has an active run")
+	ErrRunState          = errors.New("run cannot be changed in its current state")
+)
+
+// Store is the persistence used by the service (implemented by postgres.TenantStore)
+type Store interface {
+	CreateAssistant(ctx context.Context, a *domain.Assistant) error
+	UpdateAssistant(ctx context.Context, a *domain.Assistant) error
+	GetAssistant(ctx context.Context, id string) (*domain.Assistant, error)
+	ListAssistants(ctx context.Context, apiKeyID string, limit int) ([]*domain.Assistant, error)
+	DeleteAssistant(ctx context.Context, id string) (bool, error)
+	CreateRun(ctx context.Context, run *domain.Run) error
+	UpdateRun(ctx context.Context, run *domain.Run, expected domain.RunStatus) (bool, error)
+	GetRun(ctx context.Context, id string) (*domain.Run, error)
+	ListRuns(ctx context.Context, threadID string, limit int) ([]*domain.Run, error)
+}
+
+// Threads is the thread storage runs read and write (implemented by threads.Service)
+type Threads interface {
+	Get(ctx context.Context, id, apiKeyID string) (*domain.Thread, error)
+	Messages(ctx context.Context, id, apiKeyID string) ([]*domain.ThreadMessage, error)
+	Append(ctx context.Context, id, apiKeyID string, msgs []domain.Message) (*domain.Thread, error)
+}
+
+// Executor makes a run's model calls and MCP tool calls as the caller, so
+// policies, budgets, queuing and tool permissions apply as they do to chat
+// completions. A failed completion may return a *domain.RunError to set the
+// code the run fails with.
+type Executor interface {
+	Complete(ctx context.Context, req *domain.ChatRequest) (*domain.ChatResponse, error)
+	// MCPTools returns the MCP tools the caller may call, as functions; server limits them to one MCP server
+	MCPTools(ctx context.Context, server string) ([]domain.Tool, error)
+	CallMCPTool(ctx context.Context, name string, args map[string]any) (string, error)
+}
+
+// RunParams describes a new run. Empty fields take the assistant's settings.
+type RunParams struct {
+	ThreadID               string
+	AssistantID            string
+	Model                  string
+	Instructions           string
+	AdditionalInstructions string // Appended to the instructions
+	Tools                  []domain.AssistantTool
+	Temperature            *float32
+	Metadata               map[string]string
+}
+
+// ToolOutput answers a function call of a run that requires action
+type ToolOutput struct {
+	ToolCallID string
+	Output     string
+}
+
+// maxModelCalls bounds the model calls a run makes between client actions;
+// a run that reaches it ends as incomplete
+const maxModelCalls = 10
+
+// runTimeout is how long a run may stay queued, in progress or waiting for tool outputs
+const runTimeout = 10 * time.Minute
+
+// MaxListLimit caps the page size of list requests
+const MaxListLimit = 100
+
+// Service manages assistants and executes runs
+type Service struct {
+	store   Store
+	threads Threads
+
+	mu     sync.Mutex
+	active map[string]context.CancelFunc // Runs executing on this instance
+}
+
+// NewService creates a new assistants service
+func NewService(store Store, threads Threads) *Service {
+	return &Service{store: store, threads: threads, active: make(map[string]context.CancelFunc)}
+}
+
+// CreateAssistant stores a new assistant owned by apiKeyID
+func (s *Service) CreateAssistant(ctx context.Context, apiKeyID string, a domain.Assistant) (*domain.Assistant, error) {
+	a.APIKeyID = apiKeyID
+	if err := validateAssistant(&a); err != nil {
+		return nil, err
+	}
+	if err := s.store.CreateAssistant(ctx, &a); err != nil {
+		return nil, fmt.Errorf("create assistant: %w", err)
+	}
+	return &a, nil
+}
+
+// GetAssistant returns an assistant the caller can access. An empty
+// apiKeyID (a session caller) can access any assistant.
+func (s *Service) GetAssistant(ctx context.Context, id, apiKeyID string) (*domain.Assistant, error) {
+	if uuid.Validate(id) != nil {
+		return nil, ErrAssistantNotFound
+	}
+	a, err := s.store.GetAssistant(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("get assistant: %w", err)
+	}
+	if a == nil || !owns(apiKeyID, a.APIKeyID) {
+		return nil, ErrAssistantNotFound
+	}
+	return a, nil
+}
+
+// ListAssistants lists the caller's assistants, newest first
+func (s *Service) ListAssistants(ctx context.Context, apiKeyID string, limit int) ([]*domain.Assistant, error) {
+	list, err := s.store.ListAssistants(ctx, apiKeyID, clampLimit(limit))
+	if err != nil {
+		return nil, fmt.Errorf("list assistants: %w", err)
+	}
+	return list, nil
+}
+
+// UpdateAssistant saves changes to an assistant returned by GetAssistant
+func (s *Service) UpdateAssistant(ctx context.Context, a *domain.Assistant) error {
+	if err := validateAssistant(a); err != nil {
+		return err
+	}
+	if err := s.store.UpdateAssistant(ctx, a); err != nil {
+		return fmt.Errorf("update assistant: %w", err)
+	}
+	return nil
+}
+
+// DeleteAssistant removes an assistant and its runs
+func (s *Service) DeleteAssistant(ctx context.Context, id, apiKeyID string) error {
+	if _, err := s.GetAssistant(ctx, id, apiKeyID); err != nil {
+		return err
+	}
+	deleted, err := s.store.DeleteAssistant(ctx, id)
+	if err != nil {
+		return fmt.Errorf("delete assistant: %w", err)
+	}
+	if !deleted {
+		return ErrAssistantNotFound
+	}
+	return nil
+}
+
+// CreateRun starts executing an assistant on a thread. It returns at once
+// with the queued run; clients poll GetRun for its progress.
+func (s *Service) CreateRun(ctx context.Context, apiKeyID string, params RunParams, exec Executor) (*domain.Run, error) {
+	if _, err := s.threads.Get(ctx, params.ThreadID, apiKeyID); err != nil {
+		return nil, err
+	}
+	a, err := s.GetAssistant(ctx, params.AssistantID, apiKeyID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkNoActiveRun(ctx, params.ThreadID); err != nil {
+		return nil, err
+	}
+
+	run := &domain.Run{
+		ThreadID:     params.ThreadID,
+		AssistantID:  a.ID,
+		APIKeyID:     apiKeyID,
+		Status:       domain.RunStatusQueued,
+		Model:        a.Model,
+		Instructions: a.Instructions,
+		Tools:        a.Tools,
+		Temperature:  a.Temperature,
+		Metadata:     params.Metadata,
+		ExpiresAt:    time.Now().Add(runTimeout),
+	}
+	if params.Model != "" {
+		run.Model = params.Model
+	}
+	if params.Instructions != "" {
+		run.Instructions = params.Instructions
+	}
+	if params.AdditionalInstructions != "" {
+		if run.Instructions != "" {
+			run.Instructions += "\n\n"
+		}
+		run.Instructions += params.AdditionalInstructions
+	}
+	if params.Tools != nil {
+		if err := validateTools(params.Tools); err != nil {
+			return nil, err
+		}
+		run.Tools = params.Tools
+	}
+	if params.Temperature != nil {
+		run.Temperature = params.Temperature
+	}
+
+	if err := s.store.CreateRun(ctx, run); err != nil {
+		return nil, fmt.Errorf("create run: %w", err)
+	}
+	created := *run // The copy run executes with changes in the background
+	s.start(ctx, run, exec)
+	return &created, nil
+}
+
+// GetRun returns a run of a thread the caller can access. An active run past
+// its expiry is marked expired.
+func (s *Service) GetRun(ctx context.Context, threadID, runID, apiKeyID string) (*domain.Run, error) {
+	if uuid.Validate(runID) != nil {
+		return nil, ErrRunNotFound
+	}
+	run, err := s.store.GetRun(ctx, runID)
+	if err != nil {
+		return nil, fmt.Errorf("get run: %w", err)
+	}
+	if run == nil || run.ThreadID != threadID || !owns(apiKeyID, run.APIKeyID) {
+		return nil, ErrRunNotFound
+	}
+	if !run.Status.IsTerminal() && time.Now().After(run.ExpiresAt) {
+		expected := run.Status
+		run.Status = domain.RunStatusExpired
+		run.RequiredToolCalls = nil
+		if ok, err := s.store.UpdateRun(ctx, run, expected); err != nil {
+			return nil, fmt.Errorf("expire run: %w", err)
+		} else if !ok {
+			return s.GetRun(ctx, threadID, runID, apiKeyID)
+		}
+		s.stop(run.ID)
+	}
+	return run, nil
+}
+
+// ListRuns lists the runs of a thread, newest first
+func (s *Service) ListRuns(ctx context.Context, threadID, apiKeyID string, limit int) ([]*domain.Run, error) {
+	if _, err := s.threads.Get(ctx, threadID, apiKeyID); err != nil {
+		return nil, err
+	}
+	runs, err := s.store.ListRuns(ctx, threadID, clampLimit(limit))
+	if err != nil {
+		return nil, fmt.Errorf("list runs: %w", err)
+	}
+	return runs, nil
+}
+
+// SubmitToolOutputs answers the function calls of a run that requires action
+// and resumes it
+func (s *Service) SubmitToolOutputs(ctx context.Context, threadID, runID, apiKeyID string, outputs []ToolOutput, exec Executor) (*domain.Run, error) {
+	run, err := s.GetRun(ctx, threadID, runID, apiKeyID)
+	if err != nil {
+		return nil, err
+	}
+	if run.Status != domain.RunStatusRequiresAction {
+		return nil, fmt.Errorf("%w: run is %s, not requires_action", ErrRunState, run.Status)
+	}
+
+	byID := make(map[string]string, len(outputs))
+	for _, o := range outputs {
+		byID[o.ToolCallID] = o.Output
+	}
+	msgs := make([]domain.Message, 0, len(run.RequiredToolCalls))
+	for _, call := range run.RequiredToolCalls {
+		output, ok := byID[call.ID]
+		if !ok {
+			return nil, fmt.Errorf("%w: missing output for tool call %s", ErrInvalidRequest, call.ID)
+		}
+		msgs = append(msgs, toolResultMessage(call, output))
+	}
+
+	// Claim the run before writing to the thread so a second submission can't do it twice
+	run.Status = domain.RunStatusQueued
+	run.RequiredToolCalls = nil
+	run.ExpiresAt = time.Now().Add(runTimeout)
+	if ok, err := s.store.UpdateRun(ctx, run, domain.RunStatusRequiresAction); err != nil {
+		return nil, fmt.Errorf("update run: %w", err)
+	} else if !ok {
+		return nil, fmt.Errorf("%w: run changed while submitting tool outputs", ErrRunState)
+	}
+	if _, err := s.threads.Append(ctx, threadID, apiKeyID, msgs); err != nil {
+		s.fail(context.WithoutCancel(ctx), run, err)
+		return run, nil
+	}
+	resumed := *run
+	s.start(ctx, run, exec)
+	return &resumed, nil
+}
+
+// CancelRun cancels an active run. A run executing on another instance stops
+// at its next step.
+func (s *Service) CancelRun(ctx context.Context, threadID, runID, apiKeyID string) (*domain.Run, error) {
+	run, err := s.GetRun(ctx, threadID, runID, apiKeyID)
+	if err != nil {
+		return nil, err
+	}
+	expected := run.Status
+	switch expected {
+	case domain.RunStatusRequiresAction:
+		// Nothing is executing; the run ends now
+		now := time.Now()
+		run.Status, run.CancelledAt, run.RequiredToolCalls = domain.RunStatusCancelled, &now, nil
+	case domain.RunStatusQueued, domain.RunStatusInProgress:
+		run.Status = domain.RunStatusCancelling
+	case domain.RunStatusCancelling:
+		return run, nil
+	default:
+		return nil, fmt.Errorf("%w: run is already %s", ErrRunState, run.Status)
+	}
+	if ok, err := s.store.UpdateRun(ctx, run, expected); err != nil {
+		return nil, fmt.Errorf("cancel run: %w", err)
+	} else if !ok {
+		return s.CancelRun(ctx, threadID, runID, apiKeyID)
+	}
+	s.stop(run.ID)
+	return run, nil
+}
+
+// start executes a run in the background
+func (s *Service) start(ctx context.Context, run *domain.Run, exec Executor) {
+	runCtx, cancel := context.WithDeadline(context.WithoutCancel(ctx), run.ExpiresAt)
+	s.mu.Lock()
+	s.active[run.ID] = cancel
+	s.mu.Unlock()
+
+	go func() {
+		defer s.stop(run.ID)
+		s.execute(runCtx, run, exec)
+	}()
+}
+
+// stop cancels a run executing on this instance
+func (s *Service) stop(runID string) {
+	s.mu.Lock()
+	cancel, ok := s.active[runID]
+	delete(s.active, runID)
+	s.mu.Unlock()
+	if ok {
+		cancel()
+	}
+}
+
+// execute calls the model until it answers or asks for function outputs,
+// running the MCP tools it calls in between
+func (s *Service) execute(ctx context.Context, run *domain.Run, exec Executor) {
+	// Writes outlive cancellation so the final state is always recorded
+	saveCtx := context.WithoutCancel(ctx)
+
+	now := time.Now()
+	run.Status = domain.RunStatusInProgress
+	if run.StartedAt == nil {
+		run.StartedAt = &now
+	}
+	if !s.save(saveCtx, run, domain.RunStatusQueued) {
+		return
+	}
+
+	for range maxModelCalls {
+		req, mcpTools, err := s.chatRequest(ctx, run, exec)
+		if err != nil {
+			s.finish(saveCtx, ctx, run, err)
+			return
+		}
+		resp, err := exec.Complete(ctx, req)
+		if err != nil {
+			s.finish(saveCtx, ctx, run, err)
+			return
+		}
+		if resp.Usage != nil {
+			run.Usage.PromptTokens += int64(resp.Usage.PromptTokens)
+			run.Usage.CompletionTokens += int64(resp.Usage.CompletionTokens)
+			run.Usage.TotalTokens = run.Usage.PromptTokens + run.Usage.CompletionTokens
+		}
+
+		reply := domain.Message{Role: "assistant", ToolCalls: resp.ToolCalls}
+		if resp.Content != "" {
+			reply.Content = []domain.ContentBlock{{Type: "text", Text: resp.Content}}
+		}
+		msgs := []domain.Message{reply}
+
+		// Run the MCP tools now; function calls go back to the client
+		var pending []domain.ToolCall
+		for _, call := range resp.ToolCalls {
+			if !mcpTools[call.Function.Name] {
+				pending = append(pending, call)
+				continue
+			}
+			output, err := exec.CallMCPTool(ctx, call.Function.Name, call.Function.Arguments)
+			if err != nil {
+				if ctx.Err() != nil {
+					s.finish(saveCtx, ctx, run, err)
+					return
+				}
+				output = "Tool execution failed: " + err.Error()
+			}
+			msgs = append(msgs, toolResultMessage(call, output))
+		}
+		if _, err := s.threads.Append(saveCtx, run.ThreadID, run.APIKeyID, msgs); err != nil {
+			s.finish(saveCtx, ctx, run, err)
+			return
+		}
+
+		switch {
+		case len(resp.ToolCalls) == 0:
+			now := time.Now()
+			run.Status, run.CompletedAt = domain.RunStatusCompleted, &now
+			s.save(saveCtx, run, domain.RunStatusInProgress)
+			return
+		case len(pending) > 0:
+			run.Status = domain.RunStatusRequiresAction
+			run.RequiredToolCalls = pending
+			run.ExpiresAt = time.Now().Add(runTimeout)
+			s.save(saveCtx, run, domain.RunStatusInProgress)
+			return
+		}
+		// Only MCP tools were called: give the model their results
+		if !s.save(saveCtx, run, domain.RunStatusInProgress) {
+			return
+		}
+	}
+
+	now = time.Now()
+	run.Status, run.CompletedAt = domain.RunStatusIncomplete, &now
+	run.LastError = &domain.RunError{Code: "server_error", Message: fmt.Sprintf("the model made %d calls without answering", maxModelCalls)}
+	s.save(saveCtx, run, domain.RunStatusInProgress)
+}
+
+// chatRequest builds the next model call from the thread and returns the
+// names of the MCP tools it offers
+func (s *Service) chatRequest(ctx context.Context, run *domain.Run, exec Executor) (*domain.ChatRequest, map[string]bool, error) {
+	stored, err := s.threads.Messages(ctx, run.ThreadID, run.APIKeyID)
+	if err != nil {
+		return nil, nil, err
+	}
+	req := &domain.ChatRequest{
+		Model:          run.Model,
+		SystemPrompt:   run.Instructions,
+		Temperature:    run.Temperature,
+		APIKeyID:       run.APIKeyID,
+		ConversationID: run.ThreadID,
+	}
+	for _, m := range stored {
+		req.Messages = append(req.Messages, m.Message)
+	}
+
+	functions := make(map[string]bool)
+	for _, t := range run.Tools {
+		if t.Type == domain.AssistantToolFunction {
+			req.Tools = append(req.Tools, domain.Tool{Type: "function", Function: *t.Function})
+			functions[t.Function.Name] = true
+		}
+	}
+	mcpTools := make(map[string]bool)
+	for _, t := range run.Tools {
+		if t.Type != domain.AssistantToolMCP {
+			continue
+		}
+		tools, err := exec.MCPTools(ctx, t.MCPServer)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, tool := range tools {
+			// Functions the client declared take precedence
+			if functions[tool.Function.Name] || mcpTools[tool.Function.Name] {
+				continue
+			}
+			req.Tools = append(req.Tools, tool)
+			mcpTools[tool.Function.Name] = true
+		}
+	}
+	return req, mcpTools, nil
+}
+
+// finish ends a run after err: cancelled if it was cancelled or timed out, failed otherwise
+func (s *Service) finish(saveCtx, ctx context.Context, run *domain.Run, err error) {
+	if ctx.Err() == nil {
+		s.fail(saveCtx, run, err)
+		return
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		run.Status = domain.RunStatusExpired
+		s.save(saveCtx, run, domain.RunStatusInProgress)
+		return
+	}
+	// Cancelled: save finds the run cancelling and completes the cancellation
+	s.save(saveCtx, run, domain.RunStatusInProgress)
+}
+
+func (s *Service) fail(ctx context.Context, run *domain.Run, err error) {
+	expected := run.Status
+	now := time.Now()
+	run.Status, run.FailedAt = domain.RunStatusFailed, &now
+	var runErr *domain.RunError
+	if errors.As(err, &runErr) {
+		run.LastError = runErr
+	} else {
+		run.LastError = &domain.RunError{Code: "server_error", Message: err.Error()}
+	}
+	slog.Warn("Assistant run failed", "run_id", run.ID, "thread_id", run.ThreadID, "error", err)
+	s.save(ctx, run, expected)
+}
+
+// save stores the run if its status is still expected. Otherwise the run was
+// cancelled or expired meanwhile: a cancelling run is marked cancelled and
+// save returns false so execution stops.
+func (s *Service) save(ctx context.Context, run *domain.Run, expected domain.RunStatus) bool {
+	ok, err := s.store.UpdateRun(ctx, run, expected)
+	if err != nil {
+		slog.Error("Failed to save assistant run", "run_id", run.ID, "error", err)
+		return false
+	}
+	if ok {
+		return true
+	}
+
+	current, err := s.store.GetRun(ctx, run.ID)
+	if err != nil || current == nil || current.Status != domain.RunStatusCancelling {
+		return false
+	}
+	now := time.Now()
+	current.Status, current.CancelledAt = domain.RunStatusCancelled, &now
+	current.Usage = run.Usage
+	s.store.UpdateRun(ctx, current, domain.RunStatusCancelling)
+	*run = *current
+	return false
+}
+
+// checkNoActiveRun fails if the thread's latest run is still active
+func (s *Service) checkNoActiveRun(ctx context.Context, threadID string) error {
+	runs, err := s.store.ListRuns(ctx, threadID, 1)
+	if err != nil {
+		return fmt.Errorf("list runs: %w", err)
+	}
+	if len(runs) > 0 && !runs[0].Status.IsTerminal() && time.Now().Before(runs[0].ExpiresAt) {
+		return fmt.Errorf("%w %s", ErrRunActive, runs[0].ID)
+	}
+	return nil
+}
+
+func toolResultMessage(call domain.ToolCall, output string) domain.Message {
+	return domain.Message{
+		Role:       "tool",
+		ToolCallID: call.ID,
+		Content:    []domain.ContentBlock{{Type: "text", Text: output}},
+	}
+}
+
+func validateAssistant(a *domain.Assistant) error {
+	if a.Model == "" {
+		return fmt.Errorf("%w: model is required", ErrInvalidRequest)
+	}
+	if len(a.Name) > 256 {
+		return fmt.Errorf("%w: name is longer than 256 characters", ErrInvalidRequest)
+	}
+	return validateTools(a.Tools)
+}
+
+func validateTools(tools []domain.AssistantTool) error {
+	for i, t := range tools {
+		switch t.Type {
+		case domain.AssistantToolFunction:
+			if t.Function == nil || t.Function.Name == "" {
+				return fmt.Errorf("%w: tool %d is a function without a name", ErrInvalidRequest, i)
+			}
+		case domain.AssistantToolMCP:
+		default:
+			return fmt.Errorf("%w: tool type %q is not supported; use function or mcp", ErrInvalidRequest, t.Type)
+		}
+	}
+	return nil
+}
+
+// owns reports whether a caller can access a resource owned by owner
+func owns(apiKeyID, owner string) bool {
+	return apiKeyID == "" || owner == "" || owner == apiKeyID
+}
+
+func clampLimit(limit int) int {
+	if limit <= 0 {
+		return 20
+	}
+	return min(limit, MaxListLimit)
+}
@@ -0,0 +1,320 @@
+package assistants
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"modelgate/internal/domain"
+)
+
+type memStore struct {
+	mu         sync.Mutex
+	assistants map[string]*domain.Assistant
+	runs       map[string]*domain.Run
+}
+
+func newMemStore() *memStore {
+	return &memStore{assistants: make(map[string]*domain.Assistant), runs: make(map[string]*domain.Run)}
+}
+
+func (m *memStore) CreateAssistant(ctx context.Context, a *domain.Assistant) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a.ID = uuid.NewString()
+	copied := *a
+	m.assistants[a.ID] = &copied
+	return nil
+}
+
+func (m *memStore) UpdateAssistant(ctx context.Context, a *domain.Assistant) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	copied := *a
+	m.assistants[a.ID] = &copied
+	return nil
+}
+
+func (m *memStore) GetAssistant(ctx context.Context, id string) (*domain.Assistant, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a, ok := m.assistants[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *a
+	return &copied, nil
+}
+
+func (m *memStore) ListAssistants(ctx context.Context, apiKeyID string, limit int) ([]*domain.Assistant, error) {
+	return nil, nil
+}
+
+func (m *memStore) DeleteAssistant(ctx context.Context, id string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.assistants[id]
+	delete(m.assistants, id)
+	return ok, nil
+}
+
+func (m *memStore) CreateRun(ctx context.Context, run *domain.Run) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	run.ID = uuid.NewString()
+	run.CreatedAt = time.Now()
+	copied := *run
+	m.runs[run.ID] = &copied
+	return nil
+}
+
+func (m *memStore) UpdateRun(ctx context.Context, run *domain.Run, expected domain.RunStatus) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.runs[run.ID].Status != expected {
+		return false, nil
+	}
+	copied := *run
+	m.runs[run.ID] = &copied
+	return true, nil
+}
+
+func (m *memStore) GetRun(ctx context.Context, id string) (*domain.Run, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	run, ok := m.runs[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *run
+	return &copied, nil
+}
+
+func (m *memStore) ListRuns(ctx context.Context, threadID string, limit int) ([]*domain.Run, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var latest *domain.Run
+	for _, run := range m.runs {
+		if run.ThreadID == threadID && (latest == nil || run.CreatedAt.After(latest.CreatedAt)) {
+			latest = run
+		}
+	}
+	if latest == nil {
+		return nil, nil
+	}
+	copied := *latest
+	return []*domain.Run{&copied}, nil
+}
+
+type memThreads struct {
+	mu   sync.Mutex
+	msgs map[string][]*domain.ThreadMessage
+}
+
+func (t *memThreads) Get(ctx context.Context, id, apiKeyID string) (*domain.Thread, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.msgs[id]; !ok {
+		return nil, fmt.Errorf("thread %s not found", id)
+	}
+	return &domain.Thread{ID: id}, nil
+}
+
+func (t *memThreads) Messages(ctx context.Context, id, apiKeyID string) ([]*domain.ThreadMessage, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]*domain.ThreadMessage(nil), t.msgs[id]...), nil
+}
+
+func (t *memThreads) Append(ctx context.Context, id, apiKeyID string, msgs []domain.Message) (*domain.Thread, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, m := range msgs {
+		t.msgs[id] = append(t.msgs[id], &domain.ThreadMessage{ID: uuid.NewString(), ThreadID: id, Message: m})
+	}
+	return &domain.Thread{ID: id}, nil
+}
+
+// scriptedExecutor answers model calls from a script and records MCP tool calls
+type scriptedExecutor struct {
+	mu       sync.Mutex
+	replies  []*domain.ChatResponse
+	requests []*domain.ChatRequest
+	mcpCalls []string
+}
+
+func (e *scriptedExecutor) Complete(ctx context.Context, req *domain.ChatRequest) (*domain.ChatResponse, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.requests = append(e.requests, req)
+	if len(e.replies) == 0 {
+		return nil, fmt.Errorf("no scripted reply")
+	}
+	reply := e.replies[0]
+	e.replies = e.replies[1:]
+	return reply, nil
+}
+
+func (e *scriptedExecutor) MCPTools(ctx context.Context, server string) ([]domain.Tool, error) {
+	return []domain.Tool{{Type: "function", Function: domain.FunctionDefinition{Name: "github__search_issues"}}}, nil
+}
+
+func (e *scriptedExecutor) CallMCPTool(ctx context.Context, name string, args map[string]any) (string, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.mcpCalls = append(e.mcpCalls, name)
+	return `{"issues": 3}`, nil
+}
+
+func toolCall(id, name string) domain.ToolCall {
+	return domain.ToolCall{ID: id, Type: "function", Function: domain.FunctionCall{Name: name, Arguments: map[string]any{}}}
+}
+
+// waitForRun polls a run until it stops making progress
+func waitForRun(t *testing.T, svc *Service, threadID, runID string) *domain.Run {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		run, err := svc.GetRun(context.Background(), threadID, runID, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if run.Status.IsTerminal() || run.Status == domain.RunStatusRequiresAction {
+			return run
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("run did not finish")
+	return nil
+}
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+	threads := &memThreads{msgs: map[string][]*domain.ThreadMessage{"t1": {{
+		ID: "m1", ThreadID: "t1", Message: domain.Message{Role: "user", Content: []domain.ContentBlock{{Type: "text", Text: "How many open issues?"}}},
+	}}}}
+	svc := NewService(newMemStore(), threads)
+
+	a, err := svc.CreateAssistant(ctx, "", domain.Assistant{
+		Model:        "gpt-4o",
+		Instructions: "You triage issues.",
+		Tools: []domain.AssistantTool{
+			{Type: domain.AssistantToolMCP},
+			{Type: domain.AssistantToolFunction, Function: &domain.FunctionDefinition{Name: "notify"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The MCP tool runs in the gateway; the function call goes back to the client
+	exec := &scriptedExecutor{replies: []*domain.ChatResponse{
+		{ToolCalls: []domain.ToolCall{toolCall("c1", "github__search_issues")}, Usage: &domain.UsageEvent{PromptTokens: 10, CompletionTokens: 5}},
+		{ToolCalls: []domain.ToolCall{toolCall("c2", "notify")}, Usage: &domain.UsageEvent{PromptTokens: 20, CompletionTokens: 5}},
+		{Content: "There are 3 open issues.", Usage: &domain.UsageEvent{PromptTokens: 30, CompletionTokens: 8}},
+	}}
+	run, err := svc.CreateRun(ctx, "", RunParams{ThreadID: "t1", AssistantID: a.ID}, exec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.Status != domain.RunStatusQueued {
+		t.Fatalf("expected a queued run, got %s", run.Status)
+	}
+
+	run = waitForRun(t, svc, "t1", run.ID)
+	if run.Status != domain.RunStatusRequiresAction || len(run.RequiredToolCalls) != 1 || run.RequiredToolCalls[0].ID != "c2" {
+		t.Fatalf("expected the run to wait for notify, got %s %+v", run.Status, run.RequiredToolCalls)
+	}
+	if len(exec.mcpCalls) != 1 || exec.mcpCalls[0] != "github__search_issues" {
+		t.Fatalf("expected the MCP tool to run in the gateway, got %v", exec.mcpCalls)
+	}
+	if exec.requests[0].SystemPrompt != "You triage issues." || len(exec.requests[0].Tools) != 2 {
+		t.Fatalf("expected the instructions and both tools, got %+v", exec.requests[0])
+	}
+
+	// Only one run at a time on a thread
+	if _, err := svc.CreateRun(ctx, "", RunParams{ThreadID: "t1", AssistantID: a.ID}, exec); err == nil {
+		t.Fatal("expected a second run on the thread to be refused")
+	}
+
+	// Every required call needs an output
+	if _, err := svc.SubmitToolOutputs(ctx, "t1", run.ID, "", nil, exec); err == nil {
+		t.Fatal("expected missing tool outputs to be refused")
+	}
+	if _, err := svc.SubmitToolOutputs(ctx, "t1", run.ID, "", []ToolOutput{{ToolCallID: "c2", Output: "sent"}}, exec); err != nil {
+		t.Fatal(err)
+	}
+	run = waitForRun(t, svc, "t1", run.ID)
+	if run.Status != domain.RunStatusCompleted || run.CompletedAt == nil {
+		t.Fatalf("expected the run to complete, got %s %+v", run.Status, run.LastError)
+	}
+	if run.Usage.PromptTokens != 60 || run.Usage.TotalTokens != 78 {
+		t.Fatalf("expected usage summed over model calls, got %+v", run.Usage)
+	}
+
+	// The thread holds the whole exchange, ending with the answer
+	msgs, _ := threads.Messages(ctx, "t1", "")
+	roles := ""
+	for _, m := range msgs {
+		roles += m.Message.Role[:1]
+	}
+	if roles != "uatata" {
+		t.Fatalf("unexpected thread messages %q", roles)
+	}
+	if last := msgs[len(msgs)-1].Message; last.Content[0].Text != "There are 3 open issues." {
+		t.Fatalf("unexpected answer %+v", last)
+	}
+}
+
+func TestRunFailsAndCancels(t *testing.T) {
+	ctx := context.Background()
+	threads := &memThreads{msgs: map[string][]*domain.ThreadMessage{"t1": nil, "t2": nil}}
+	svc := NewService(newMemStore(), threads)
+	a, err := svc.CreateAssistant(ctx, "key-1", domain.Assistant{
+		Model: "gpt-4o",
+		Tools: []domain.AssistantTool{{Type: domain.AssistantToolFunction, Function: &domain.FunctionDefinition{Name: "lookup"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Other keys can't see the assistant
+	if _, err := svc.GetAssistant(ctx, a.ID, "key-2"); err != ErrAssistantNotFound {
+		t.Fatalf("expected the assistant to be hidden from another key, got %v", err)
+	}
+	if _, err := svc.CreateAssistant(ctx, "key-1", domain.Assistant{Model: "gpt-4o", Tools: []domain.AssistantTool{{Type: "code_interpreter"}}}); err == nil {
+		t.Fatal("expected an unsupported tool type to be refused")
+	}
+
+	// A model error fails the run with the error as last_error
+	run, err := svc.CreateRun(ctx, "key-1", RunParams{ThreadID: "t1", AssistantID: a.ID}, &scriptedExecutor{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	run = waitForRun(t, svc, "t1", run.ID)
+	if run.Status != domain.RunStatusFailed || run.LastError == nil || run.LastError.Code != "server_error" {
+		t.Fatalf("expected a failed run, got %s %+v", run.Status, run.LastError)
+	}
+
+	// A run waiting for tool outputs is cancelled at once
+	exec := &scriptedExecutor{replies: []*domain.ChatResponse{{ToolCalls: []domain.ToolCall{toolCall("c1", "lookup")}}}}
+	run, err = svc.CreateRun(ctx, "key-1", RunParams{ThreadID: "t2", AssistantID: a.ID}, exec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitForRun(t, svc, "t2", run.ID)
+	run, err = svc.CancelRun(ctx, "t2", run.ID, "key-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.Status != domain.RunStatusCancelled || run.CancelledAt == nil {
+		t.Fatalf("expected a cancelled run, got %s", run.Status)
+	}
+	if _, err := svc.CancelRun(ctx, "t2", run.ID, "key-1"); err == nil {
+		t.Fatal("expected cancelling a finished run to fail")
+	}
+}
@@ -0,0 +1,104 @@
+package attestation
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+// HeaderName is the response header, or trailer on streamed responses,
+// carrying the attestation
+const HeaderName = "X-ModelGate-Attestation"
+
+// version prefixes the signed message and the header, so the format can change
+const version = "v1"
+
+// Hash returns the hex SHA-256 of a request or response body
+func Hash(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// PolicyVersion digests the role policies enforced on a request. It changes
+// whenever any of them is edited.
+func PolicyVersion(policies []*domain.RolePolicy) string {
+	sorted := make([]*domain.RolePolicy, 0, len(policies))
+	for _, p := range policies {
+		if p != nil {
+			sorted = append(sorted, p)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+	h := sha256.New()
+	for _, p := range sorted {
+		b, _ := json.Marshal(p)
+		h.Write(b)
+		h.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// Message returns the bytes an attestation's signature covers
+func Message(a *domain.ResponseAttestation) []byte {
+	return []byte(strings.Join([]string{
+		"modelgate-attestation-" + version,
+		a.RequestID,
+		a.RequestHash,
+		a.ResponseHash,
+		a.PolicyVersion,
+		strconv.FormatInt(a.SignedAt.Unix(), 10),
+	}, "\n"))
+}
+
+// FormatHeader encodes an attestation as a header value
+func FormatHeader(a *domain.ResponseAttestation) string {
+	return fmt.Sprintf("%s; key=%s; request_id=%s; ts=%d; policy=%s; req=%s; resp=%s; sig=%s",
+		version, a.KeyID, a.RequestID, a.SignedAt.Unix(), a.PolicyVersion, a.RequestHash, a.ResponseHash,
+		base64.RawURLEncoding.EncodeToString(a.Signature))
+}
+
+// ParseHeader decodes a header value written by FormatHeader
+func ParseHeader(value string) (*domain.ResponseAttestation, error) {
+	parts := strings.Split(value, ";")
+	if strings.TrimSpace(parts[0]) != version {
+		return nil, fmt.Errorf("unsupported attestation version %q", strings.TrimSpace(parts[0]))
+	}
+	fields := make(map[string]string, len(parts)-1)
+	for _, part := range parts[1:] {
+		k, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return nil, fmt.Errorf("malformed attestation field %q", part)
+		}
+		fields[k] = v
+	}
+	for _, k := range []string{"key", "request_id", "ts", "policy", "req", "resp", "sig"} {
+		if fields[k] == "" {
+			return nil, fmt.Errorf("attestation is missing %s", k)
+		}
+	}
+	ts, err := strconv.ParseInt(fields["ts"], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid attestation ts: %w", err)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(fields["sig"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid attestation sig: %w", err)
+	}
+	return &domain.ResponseAttestation{
+		RequestID:     fields["request_id"],
+		KeyID:         fields["key"],
+		RequestHash:   fields["req"],
+		ResponseHash:  fields["resp"],
+		PolicyVersion: fields["policy"],
+		SignedAt:      time.Unix(ts, 0).UTC(),
+		Signature:     sig,
+	}, nil
+}
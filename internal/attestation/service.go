@@ -0,0 +1,287 @@
+// Package attestation signs chat completion responses for compliance. Each
+// successful response gets an Ed25519 signature over the hash of the request
+// body, the hash of the response body, the version of the policies enforced
+// and the signing time. The signature is returned to the client and stored
+// next to the usage record, and can be verified later with the public key,
+// which stays published after the key is rotated out.
+package attestation
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+)
+
+// Store is the persistence used by the service (implemented by postgres.TenantStore)
+type Store interface {
+	CreateAttestationKey(ctx context.Context, key *domain.AttestationKey, seed []byte) error
+	GetActiveAttestationKey(ctx context.Context) (*domain.AttestationKey, []byte, error)
+	ListAttestationKeys(ctx context.Context) ([]domain.AttestationKey, error)
+	InsertResponseAttestations(ctx context.Context, atts []domain.ResponseAttestation) error
+	GetResponseAttestation(ctx context.Context, requestID string) (*domain.ResponseAttestation, error)
+	DeleteResponseAttestations(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+const (
+	// refreshInterval bounds how long a key rotated on another instance keeps
+	// signing here
+	refreshInterval = time.Minute
+	// flushInterval is the longest a signed attestation waits to be stored
+	flushInterval = time.Second
+	// cleanupInterval is how often expired attestations are deleted
+	cleanupInterval = time.Hour
+	bufferSize      = 10000
+	batchSize       = 500
+)
+
+var (
+	// ErrUnknownKey is returned when verifying an attestation signed by a key
+	// this gateway never had
+	ErrUnknownKey = errors.New("unknown attestation key")
+	// ErrInvalidSignature is returned when the signature doesn't match
+	ErrInvalidSignature = errors.New("invalid attestation signature")
+)
+
+// signer is the key currently signing
+type signer struct {
+	key     domain.AttestationKey
+	private ed25519.PrivateKey
+}
+
+// Service signs, stores and verifies response attestations
+type Service struct {
+	cfg   config.AttestationConfig
+	store Store
+	now   func() time.Time
+
+	signer     atomic.Pointer[signer]
+	publicKeys sync.Map // Key ID -> ed25519.PublicKey
+	pending    chan domain.ResponseAttestation
+	dropped    atomic.Int64
+}
+
+// NewService creates an attestation service. It signs nothing until Start
+// loaded or created a key.
+func NewService(cfg config.AttestationConfig, store Store) *Service {
+	return &Service{
+		cfg:     cfg,
+		store:   store,
+		now:     time.Now,
+		pending: make(chan domain.ResponseAttestation, bufferSize),
+	}
+}
+
+// Enabled reports whether responses should be signed
+func (s *Service) Enabled() bool {
+	return s != nil && s.cfg.Enabled && s.signer.Load() != nil
+}
+
+// Start loads the signing key, creating the first one if needed, then stores
+// attestations, rotates the key and deletes expired attestations until ctx is
+// done
+func (s *Service) Start(ctx context.Context) {
+	if !s.cfg.Enabled {
+		return
+	}
+	s.refresh(ctx)
+	slog.Info("Response attestations enabled", "rotate_after", s.cfg.RotateAfter)
+
+	go func() {
+		flush := time.NewTicker(flushInterval)
+		defer flush.Stop()
+		refresh := time.NewTicker(refreshInterval)
+		defer refresh.Stop()
+		cleanup := time.NewTicker(cleanupInterval)
+		defer cleanup.Stop()
+
+		batch := make([]domain.ResponseAttestation, 0, batchSize)
+		for {
+			select {
+			case <-ctx.Done():
+				for {
+					select {
+					case a := <-s.pending:
+						batch = append(batch, a)
+					default:
+						s.flush(context.WithoutCancel(ctx), batch)
+						return
+					}
+				}
+			case a := <-s.pending:
+				batch = append(batch, a)
+				if len(batch) >= batchSize {
+					s.flush(ctx, batch)
+					batch = batch[:0]
+				}
+			case <-flush.C:
+				if len(batch) > 0 {
+					s.flush(ctx, batch)
+					batch = batch[:0]
+				}
+			case <-refresh.C:
+				s.refresh(ctx)
+			case <-cleanup.C:
+				s.cleanup(ctx)
+			}
+		}
+	}()
+}
+
+// refresh loads the active key, which another instance may have rotated, and
+// rotates it once it is older than rotate_after
+func (s *Service) refresh(ctx context.Context) {
+	key, seed, err := s.store.GetActiveAttestationKey(ctx)
+	if err != nil {
+		slog.Warn("Failed to load attestation key", "error", err)
+		return
+	}
+	if key == nil || (s.cfg.RotateAfter > 0 && s.now().Sub(key.CreatedAt) >= s.cfg.RotateAfter) {
+		if _, err := s.Rotate(ctx, "system"); err == nil {
+			return
+		}
+		// Another instance may have rotated at the same time
+		if key, seed, err = s.store.GetActiveAttestationKey(ctx); err != nil || key == nil {
+			slog.Warn("Failed to create attestation key", "error", err)
+			return
+		}
+	}
+	if len(seed) != ed25519.SeedSize {
+		slog.Error("Attestation key has an invalid seed", "key_id", key.ID)
+		return
+	}
+	s.use(*key, ed25519.NewKeyFromSeed(seed))
+}
+
+func (s *Service) use(key domain.AttestationKey, private ed25519.PrivateKey) {
+	s.publicKeys.Store(key.ID, ed25519.PublicKey(key.PublicKey))
+	if current := s.signer.Load(); current == nil || current.key.ID != key.ID {
+		slog.Info("Signing response attestations", "key_id", key.ID)
+	}
+	s.signer.Store(&signer{key: key, private: private})
+}
+
+// Rotate creates a new signing key and retires the current one, which can
+// still verify what it signed
+func (s *Service) Rotate(ctx context.Context, createdBy string) (*domain.AttestationKey, error) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	fingerprint := sha256.Sum256(public)
+	key := domain.AttestationKey{
+		ID:        hex.EncodeToString(fingerprint[:16]),
+		PublicKey: public,
+		CreatedBy: createdBy,
+		CreatedAt: s.now().UTC(),
+	}
+	if err := s.store.CreateAttestationKey(ctx, &key, private.Seed()); err != nil {
+		return nil, fmt.Errorf("store attestation key: %w", err)
+	}
+	s.use(key, private)
+	return &key, nil
+}
+
+// Keys returns every signing key, newest first
+func (s *Service) Keys(ctx context.Context) ([]domain.AttestationKey, error) {
+	return s.store.ListAttestationKeys(ctx)
+}
+
+// Sign signs the hashes of a request and its response with the active key
+func (s *Service) Sign(requestID, requestHash, responseHash, policyVersion string) (*domain.ResponseAttestation, error) {
+	current := s.signer.Load()
+	if current == nil {
+		return nil, fmt.Errorf("no attestation key loaded")
+	}
+	a := &domain.ResponseAttestation{
+		RequestID:     requestID,
+		KeyID:         current.key.ID,
+		RequestHash:   requestHash,
+		ResponseHash:  responseHash,
+		PolicyVersion: policyVersion,
+		SignedAt:      s.now().UTC().Truncate(time.Second),
+	}
+	a.Signature = ed25519.Sign(current.private, Message(a))
+	return a, nil
+}
+
+// Record queues an attestation to be stored. It never blocks: when the
+// buffer is full the attestation is dropped and counted.
+func (s *Service) Record(a *domain.ResponseAttestation) {
+	select {
+	case s.pending <- *a:
+	default:
+		if s.dropped.Add(1)%1000 == 1 {
+			slog.Warn("Attestation buffer full, dropping attestations", "dropped", s.dropped.Load())
+		}
+	}
+}
+
+// Lookup returns the attestation stored for a request, or nil
+func (s *Service) Lookup(ctx context.Context, requestID string) (*domain.ResponseAttestation, error) {
+	return s.store.GetResponseAttestation(ctx, requestID)
+}
+
+// Verify checks an attestation's signature against the key that made it
+func (s *Service) Verify(ctx context.Context, a *domain.ResponseAttestation) error {
+	public, err := s.publicKey(ctx, a.KeyID)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(public, Message(a), a.Signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func (s *Service) publicKey(ctx context.Context, id string) (ed25519.PublicKey, error) {
+	if public, ok := s.publicKeys.Load(id); ok {
+		return public.(ed25519.PublicKey), nil
+	}
+	keys, err := s.store.ListAttestationKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		s.publicKeys.Store(key.ID, ed25519.PublicKey(key.PublicKey))
+	}
+	if public, ok := s.publicKeys.Load(id); ok {
+		return public.(ed25519.PublicKey), nil
+	}
+	return nil, ErrUnknownKey
+}
+
+// flush stores a batch. Failures are logged; the batch is not retried.
+func (s *Service) flush(ctx context.Context, batch []domain.ResponseAttestation) {
+	if len(batch) == 0 {
+		return
+	}
+	if err := s.store.InsertResponseAttestations(ctx, batch); err != nil {
+		slog.Error("Failed to store response attestations", "attestations", len(batch), "error", err)
+	}
+}
+
+// cleanup deletes attestations signed longer ago than the retention
+func (s *Service) cleanup(ctx context.Context) {
+	if s.cfg.Retention <= 0 {
+		return
+	}
+	deleted, err := s.store.DeleteResponseAttestations(ctx, s.now().Add(-s.cfg.Retention))
+	if err != nil {
+		slog.Warn("Failed to delete expired response attestations", "error", err)
+		return
+	}
+	if deleted > 0 {
+		slog.Debug("Deleted expired response attestations", "count", deleted)
+	}
+}
@@ -0,0 +1,130 @@
+package attestation
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+)
+
+// memStore keeps keys and attestations in memory
+type memStore struct {
+	keys  []domain.AttestationKey
+	seeds map[string][]byte
+	atts  map[string]domain.ResponseAttestation
+}
+
+func newMemStore() *memStore {
+	return &memStore{seeds: map[string][]byte{}, atts: map[string]domain.ResponseAttestation{}}
+}
+
+func (m *memStore) CreateAttestationKey(ctx context.Context, key *domain.AttestationKey, seed []byte) error {
+	for i := range m.keys {
+		if m.keys[i].RetiredAt == nil {
+			retired := key.CreatedAt
+			m.keys[i].RetiredAt = &retired
+		}
+	}
+	m.keys = append([]domain.AttestationKey{*key}, m.keys...)
+	m.seeds[key.ID] = seed
+	return nil
+}
+
+func (m *memStore) GetActiveAttestationKey(ctx context.Context) (*domain.AttestationKey, []byte, error) {
+	for _, k := range m.keys {
+		if k.RetiredAt == nil {
+			return &k, m.seeds[k.ID], nil
+		}
+	}
+	return nil, nil, nil
+}
+
+func (m *memStore) ListAttestationKeys(ctx context.Context) ([]domain.AttestationKey, error) {
+	return m.keys, nil
+}
+
+func (m *memStore) InsertResponseAttestations(ctx context.Context, atts []domain.ResponseAttestation) error {
+	for _, a := range atts {
+		m.atts[a.RequestID] = a
+	}
+	return nil
+}
+
+func (m *memStore) GetResponseAttestation(ctx context.Context, requestID string) (*domain.ResponseAttestation, error) {
+	if a, ok := m.atts[requestID]; ok {
+		return &a, nil
+	}
+	return nil, nil
+}
+
+func (m *memStore) DeleteResponseAttestations(ctx context.Context, cutoff time.Time) (int64, error) {
+	return 0, nil
+}
+
+func TestSignAndVerify(t *testing.T) {
+	ctx := context.Background()
+	store := newMemStore()
+	svc := NewService(config.AttestationConfig{Enabled: true, RotateAfter: 24 * time.Hour}, store)
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+
+	svc.refresh(ctx)
+	if !svc.Enabled() || len(store.keys) != 1 {
+		t.Fatalf("expected a key to be created, got %d", len(store.keys))
+	}
+
+	signed, err := svc.Sign("req-1", Hash([]byte(`{"model":"gpt-4o"}`)), Hash([]byte(`{"id":"c1"}`)), "p1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The header carries everything needed to verify
+	parsed, err := ParseHeader(FormatHeader(signed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Verify(ctx, parsed); err != nil {
+		t.Fatalf("expected a valid signature, got %v", err)
+	}
+	tampered := *parsed
+	tampered.ResponseHash = Hash([]byte(`{"id":"c2"}`))
+	if err := svc.Verify(ctx, &tampered); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("expected a tampered response to fail, got %v", err)
+	}
+
+	// A key older than rotate_after is replaced; the old one still verifies
+	now = now.Add(25 * time.Hour)
+	svc.refresh(ctx)
+	if len(store.keys) != 2 || store.keys[1].RetiredAt == nil {
+		t.Fatalf("expected the key to be rotated, got %+v", store.keys)
+	}
+	fresh := NewService(config.AttestationConfig{Enabled: true}, store)
+	if err := fresh.Verify(ctx, parsed); err != nil {
+		t.Fatalf("expected a retired key to verify, got %v", err)
+	}
+	next, _ := svc.Sign("req-2", signed.RequestHash, signed.ResponseHash, "p1")
+	if next.KeyID == signed.KeyID {
+		t.Fatal("expected the new key to sign")
+	}
+
+	unknown := *parsed
+	unknown.KeyID = "missing"
+	if err := fresh.Verify(ctx, &unknown); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("expected an unknown key, got %v", err)
+	}
+}
+
+func TestPolicyVersion(t *testing.T) {
+	a := &domain.RolePolicy{ID: "a", RoleID: "r1"}
+	b := &domain.RolePolicy{ID: "b", RoleID: "r2"}
+	if PolicyVersion([]*domain.RolePolicy{a, b}) != PolicyVersion([]*domain.RolePolicy{b, a}) {
+		t.Fatal("expected the version not to depend on policy order")
+	}
+	before := PolicyVersion([]*domain.RolePolicy{a, b})
+	b.BudgetPolicy.Enabled = true
+	if PolicyVersion([]*domain.RolePolicy{a, b}) == before {
+		t.Fatal("expected an edited policy to change the version")
+	}
+}
@@ -0,0 +1,113 @@
+package auditexport
+
+import (
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"modelgate/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+var csvHeader = []string{
+	"timestamp", "id", "action", "resource_type", "resource_id", "resource_name",
+	"actor_id", "actor_email", "actor_type", "ip_address", "user_agent",
+	"status", "error_message", "details", "old_value", "new_value",
+}
+
+// encoder writes audit logs as CSV or JSON Lines
+type encoder struct {
+	format string
+	csv    *csv.Writer
+	json   *json.Encoder
+}
+
+func newEncoder(format string, w io.Writer) (*encoder, error) {
+	switch format {
+	case "csv":
+		e := &encoder{format: format, csv: csv.NewWriter(w)}
+		return e, e.csv.Write(csvHeader)
+	case "jsonl":
+		return &encoder{format: format, json: json.NewEncoder(w)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported export format %q (use csv or jsonl)", format)
+	}
+}
+
+func (e *encoder) write(log domain.AuditLog) error {
+	if e.json != nil {
+		return e.json.Encode(log)
+	}
+	return e.csv.Write([]string{
+		log.Timestamp.UTC().Format(time.RFC3339Nano),
+		log.ID,
+		string(log.Action),
+		string(log.ResourceType),
+		log.ResourceID,
+		log.ResourceName,
+		log.ActorID,
+		log.ActorEmail,
+		log.ActorType,
+		log.IPAddress,
+		log.UserAgent,
+		log.Status,
+		log.ErrorMessage,
+		jsonField(log.Details),
+		jsonField(log.OldValue),
+		jsonField(log.NewValue),
+	})
+}
+
+func (e *encoder) flush() error {
+	if e.csv != nil {
+		e.csv.Flush()
+		return e.csv.Error()
+	}
+	return nil
+}
+
+func jsonField(v map[string]any) string {
+	if len(v) == 0 {
+		return ""
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func contentType(format string) string {
+	if format == "csv" {
+		return "text/csv"
+	}
+	return "application/x-ndjson"
+}
+
+// EncodeCursor returns the opaque cursor pointing just after log
+func EncodeCursor(log domain.AuditLog) string {
+	raw := log.Timestamp.UTC().Format(time.RFC3339Nano) + "|" + log.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor parses a cursor returned by EncodeCursor
+func DecodeCursor(cursor string) (*domain.AuditLogCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	ts, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	t, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	return &domain.AuditLogCursor{Timestamp: t, ID: id}, nil
+}
@@ -0,0 +1,228 @@
+// Package auditexport pages through the audit log with stable cursors and runs
+// asynchronous CSV/JSONL exports for compliance reviews.
+package auditexport
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+	"modelgate/internal/objectstore"
+
+	"github.com/google/uuid"
+)
+
+const (
+	exportPageSize = 1000 // Rows read per query while exporting
+	maxPageSize    = 1000 // Largest page a client may request
+)
+
+// ErrDownloadUnavailable is returned when an export can't be downloaded: the
+// token is wrong, it has not finished, it was written to object storage, or it expired
+var ErrDownloadUnavailable = errors.New("export is not available for download")
+
+// Store is the persistence used by the service (implemented by postgres.TenantStore)
+type Store interface {
+	ListAuditLogsPage(ctx context.Context, filter domain.AuditLogFilter, after *domain.AuditLogCursor, limit int) ([]domain.AuditLog, error)
+	CreateAuditExportJob(ctx context.Context, job *domain.AuditExportJob) error
+	UpdateAuditExportJob(ctx context.Context, job *domain.AuditExportJob, content []byte) error
+	GetAuditExportJob(ctx context.Context, id string) (*domain.AuditExportJob, error)
+	ListAuditExportJobs(ctx context.Context, limit int) ([]*domain.AuditExportJob, error)
+	GetAuditExportContent(ctx context.Context, id string) ([]byte, error)
+	PurgeExpiredAuditExports(ctx context.Context, before time.Time) error
+}
+
+// Page is one page of audit logs in export order
+type Page struct {
+	Items     []domain.AuditLog
+	EndCursor string // Cursor of the last item; empty if the page is empty
+	HasMore   bool
+}
+
+// Service pages through audit logs and runs export jobs
+type Service struct {
+	config config.AuditExportConfig
+	store  Store
+	dest   objectstore.Store // nil keeps finished files in the database
+}
+
+// NewService creates a new audit export service. dest may be nil.
+func NewService(cfg config.AuditExportConfig, store Store, dest objectstore.Store) *Service {
+	return &Service{config: cfg, store: store, dest: dest}
+}
+
+// Page returns up to limit audit logs matching the filter, oldest first,
+// after the given cursor (empty starts at the beginning)
+func (s *Service) Page(ctx context.Context, filter domain.AuditLogFilter, limit int, cursor string) (*Page, error) {
+	if limit <= 0 || limit > maxPageSize {
+		limit = maxPageSize
+	}
+	var after *domain.AuditLogCursor
+	if cursor != "" {
+		var err error
+		if after, err = DecodeCursor(cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	// Fetch one extra row to learn whether another page follows
+	logs, err := s.store.ListAuditLogsPage(ctx, filter, after, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("list audit logs: %w", err)
+	}
+	page := &Page{Items: logs}
+	if len(logs) > limit {
+		page.Items = logs[:limit]
+		page.HasMore = true
+	}
+	if len(page.Items) > 0 {
+		page.EndCursor = EncodeCursor(page.Items[len(page.Items)-1])
+	}
+	return page, nil
+}
+
+// Start creates an export job and runs it in the background
+func (s *Service) Start(ctx context.Context, filter domain.AuditLogFilter, format, requestedBy string) (*domain.AuditExportJob, error) {
+	if format != "csv" && format != "jsonl" {
+		return nil, fmt.Errorf("unsupported export format %q (use csv or jsonl)", format)
+	}
+	if err := s.store.PurgeExpiredAuditExports(ctx, time.Now()); err != nil {
+		slog.Warn("Failed to purge expired audit exports", "error", err)
+	}
+
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return nil, err
+	}
+	filter.Limit, filter.Offset = 0, 0
+	job := &domain.AuditExportJob{
+		ID:            uuid.New().String(),
+		Status:        domain.AuditExportStatusPending,
+		Format:        format,
+		Filter:        filter,
+		RequestedBy:   requestedBy,
+		DownloadToken: hex.EncodeToString(token),
+		CreatedAt:     time.Now(),
+	}
+	if err := s.store.CreateAuditExportJob(ctx, job); err != nil {
+		return nil, fmt.Errorf("create export job: %w", err)
+	}
+
+	go s.run(context.WithoutCancel(ctx), *job)
+	return job, nil
+}
+
+// Job returns an export job, or nil if it does not exist
+func (s *Service) Job(ctx context.Context, id string) (*domain.AuditExportJob, error) {
+	return s.store.GetAuditExportJob(ctx, id)
+}
+
+// Jobs returns the most recent export jobs
+func (s *Service) Jobs(ctx context.Context, limit int) ([]*domain.AuditExportJob, error) {
+	return s.store.ListAuditExportJobs(ctx, limit)
+}
+
+// Download returns a finished export kept in the database after checking its token
+func (s *Service) Download(ctx context.Context, id, token string) (*domain.AuditExportJob, []byte, error) {
+	job, err := s.store.GetAuditExportJob(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if job == nil || job.Status != domain.AuditExportStatusCompleted || job.ObjectKey != "" ||
+		subtle.ConstantTimeCompare([]byte(job.DownloadToken), []byte(token)) != 1 ||
+		job.ExpiresAt == nil || time.Now().After(*job.ExpiresAt) {
+		return nil, nil, ErrDownloadUnavailable
+	}
+	content, err := s.store.GetAuditExportContent(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if content == nil {
+		return nil, nil, ErrDownloadUnavailable
+	}
+	return job, content, nil
+}
+
+// FileName is the name a downloaded export is saved as
+func FileName(job *domain.AuditExportJob) string {
+	return fmt.Sprintf("audit-logs-%s.%s", job.CreatedAt.UTC().Format("20060102T150405"), job.Format)
+}
+
+// run writes every matching audit log and records the result on the job
+func (s *Service) run(ctx context.Context, job domain.AuditExportJob) {
+	job.Status = domain.AuditExportStatusRunning
+	if err := s.store.UpdateAuditExportJob(ctx, &job, nil); err != nil {
+		slog.Error("Failed to update audit export job", "job_id", job.ID, "error", err)
+	}
+
+	content, err := s.export(ctx, &job)
+	now := time.Now()
+	job.CompletedAt = &now
+	if err != nil {
+		job.Status = domain.AuditExportStatusFailed
+		job.Error = err.Error()
+		content = nil
+		slog.Error("Audit export failed", "job_id", job.ID, "error", err)
+	} else {
+		job.Status = domain.AuditExportStatusCompleted
+		if job.ObjectKey == "" {
+			expires := now.Add(s.config.DownloadTTL)
+			job.ExpiresAt = &expires
+		}
+		slog.Info("Audit export completed", "job_id", job.ID, "rows", job.RowCount, "key", job.ObjectKey)
+	}
+	if err := s.store.UpdateAuditExportJob(ctx, &job, content); err != nil {
+		slog.Error("Failed to update audit export job", "job_id", job.ID, "error", err)
+	}
+}
+
+// export encodes the job's audit logs page by page. It uploads the file when
+// an object store is configured and otherwise returns it for the database.
+func (s *Service) export(ctx context.Context, job *domain.AuditExportJob) ([]byte, error) {
+	var buf bytes.Buffer
+	enc, err := newEncoder(job.Format, &buf)
+	if err != nil {
+		return nil, err
+	}
+
+	var after *domain.AuditLogCursor
+	for {
+		logs, err := s.store.ListAuditLogsPage(ctx, job.Filter, after, exportPageSize)
+		if err != nil {
+			return nil, fmt.Errorf("list audit logs: %w", err)
+		}
+		for _, log := range logs {
+			if err := enc.write(log); err != nil {
+				return nil, fmt.Errorf("encode %s: %w", job.Format, err)
+			}
+		}
+		job.RowCount += int64(len(logs))
+		if len(logs) < exportPageSize {
+			break
+		}
+		last := logs[len(logs)-1]
+		after = &domain.AuditLogCursor{Timestamp: last.Timestamp, ID: last.ID}
+	}
+	if err := enc.flush(); err != nil {
+		return nil, fmt.Errorf("encode %s: %w", job.Format, err)
+	}
+	job.SizeBytes = int64(buf.Len())
+
+	if s.dest == nil {
+		return buf.Bytes(), nil
+	}
+	key := fmt.Sprintf("audit_logs/%s/%s", job.CreatedAt.UTC().Format("2006-01-02"), FileName(job))
+	if err := s.dest.Put(ctx, key, buf.Bytes(), contentType(job.Format)); err != nil {
+		return nil, err
+	}
+	job.ObjectKey = key
+	return nil, nil
+}
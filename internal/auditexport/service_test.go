@@ -0,0 +1,152 @@
+package auditexport
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// memStore is an in-memory Store for tests; logs are kept oldest first
+type memStore struct {
+	logs    []domain.AuditLog
+	jobs    map[string]*domain.AuditExportJob
+	content map[string][]byte
+}
+
+func (m *memStore) ListAuditLogsPage(_ context.Context, filter domain.AuditLogFilter, after *domain.AuditLogCursor, limit int) ([]domain.AuditLog, error) {
+	var out []domain.AuditLog
+	for _, log := range m.logs {
+		if after != nil && !log.Timestamp.After(after.Timestamp) {
+			continue
+		}
+		if filter.ActorID != "" && log.ActorID != filter.ActorID {
+			continue
+		}
+		if len(out) == limit {
+			break
+		}
+		out = append(out, log)
+	}
+	return out, nil
+}
+
+func (m *memStore) CreateAuditExportJob(_ context.Context, job *domain.AuditExportJob) error {
+	copied := *job
+	m.jobs[job.ID] = &copied
+	return nil
+}
+
+func (m *memStore) UpdateAuditExportJob(_ context.Context, job *domain.AuditExportJob, content []byte) error {
+	copied := *job
+	m.jobs[job.ID] = &copied
+	m.content[job.ID] = content
+	return nil
+}
+
+func (m *memStore) GetAuditExportJob(_ context.Context, id string) (*domain.AuditExportJob, error) {
+	return m.jobs[id], nil
+}
+
+func (m *memStore) ListAuditExportJobs(context.Context, int) ([]*domain.AuditExportJob, error) {
+	return nil, nil
+}
+
+func (m *memStore) GetAuditExportContent(_ context.Context, id string) ([]byte, error) {
+	return m.content[id], nil
+}
+
+func (m *memStore) PurgeExpiredAuditExports(context.Context, time.Time) error { return nil }
+
+func newMemStore(n int) *memStore {
+	m := &memStore{jobs: map[string]*domain.AuditExportJob{}, content: map[string][]byte{}}
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < n; i++ {
+		actor := "alice"
+		if i%2 == 1 {
+			actor = "bob"
+		}
+		m.logs = append(m.logs, domain.AuditLog{
+			ID:           uuid.New().String(),
+			Timestamp:    start.Add(time.Duration(i) * time.Minute),
+			Action:       domain.AuditActionUpdate,
+			ResourceType: domain.AuditResourceRole,
+			ResourceID:   "role-1",
+			ActorID:      actor,
+			Status:       "success",
+			Details:      map[string]any{"seq": i},
+		})
+	}
+	return m
+}
+
+func TestPageFollowsCursor(t *testing.T) {
+	svc := NewService(config.AuditExportConfig{}, newMemStore(5), nil)
+	ctx := context.Background()
+
+	var seen []string
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > 5 {
+			t.Fatal("pagination did not terminate")
+		}
+		page, err := svc.Page(ctx, domain.AuditLogFilter{}, 2, cursor)
+		if err != nil {
+			t.Fatalf("Page: %v", err)
+		}
+		for _, log := range page.Items {
+			seen = append(seen, log.ID)
+		}
+		if !page.HasMore {
+			break
+		}
+		cursor = page.EndCursor
+	}
+	if len(seen) != 5 {
+		t.Fatalf("expected 5 logs across pages, got %d", len(seen))
+	}
+
+	if _, err := svc.Page(ctx, domain.AuditLogFilter{}, 2, "not-a-cursor"); err == nil {
+		t.Fatal("expected an invalid cursor to be rejected")
+	}
+}
+
+func TestExportJobWritesDownloadableCSV(t *testing.T) {
+	store := newMemStore(3)
+	svc := NewService(config.AuditExportConfig{DownloadTTL: time.Hour}, store, nil)
+	ctx := context.Background()
+
+	job := domain.AuditExportJob{
+		ID:            uuid.New().String(),
+		Format:        "csv",
+		Filter:        domain.AuditLogFilter{ActorID: "alice"},
+		DownloadToken: "secret",
+		CreatedAt:     time.Now(),
+	}
+	store.CreateAuditExportJob(ctx, &job)
+	svc.run(ctx, job)
+
+	done, content, err := svc.Download(ctx, job.ID, "secret")
+	if err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	if done.Status != domain.AuditExportStatusCompleted || done.RowCount != 2 {
+		t.Fatalf("expected a completed export of 2 rows, got %s with %d", done.Status, done.RowCount)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "timestamp,id,action") {
+		t.Fatalf("unexpected CSV:\n%s", content)
+	}
+	if !strings.Contains(lines[1], `"{""seq"":0}"`) {
+		t.Fatalf("expected details as JSON, got %s", lines[1])
+	}
+
+	if _, _, err := svc.Download(ctx, job.ID, "wrong"); err != ErrDownloadUnavailable {
+		t.Fatalf("expected a wrong token to be refused, got %v", err)
+	}
+}
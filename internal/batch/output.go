@@ -0,0 +1,128 @@
+// Package batch runs batch jobs through providers' native batch APIs.
+package batch
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"modelgate/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// outputLine is one line of an OpenAI-compatible batch output file
+type outputLine struct {
+	ID       string          `json:"id"`
+	CustomID string          `json:"custom_id"`
+	Response *outputResponse `json:"response"`
+	Error    *outputError    `json:"error"`
+}
+
+type outputResponse struct {
+	StatusCode int            `json:"status_code"`
+	RequestID  string         `json:"request_id"`
+	Body       chatCompletion `json:"body"`
+}
+
+type outputError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type chatCompletion struct {
+	ID      string           `json:"id"`
+	Object  string           `json:"object"`
+	Created int64            `json:"created"`
+	Model   string           `json:"model"`
+	Choices []chatChoice     `json:"choices"`
+	Usage   *completionUsage `json:"usage,omitempty"`
+}
+
+type chatChoice struct {
+	Index        int         `json:"index"`
+	Message      chatMessage `json:"message"`
+	FinishReason string      `json:"finish_reason"`
+}
+
+type chatMessage struct {
+	Role      string     `json:"role"`
+	Content   string     `json:"content"`
+	ToolCalls []toolCall `json:"tool_calls,omitempty"`
+}
+
+type toolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+type completionUsage struct {
+	PromptTokens     int32   `json:"prompt_tokens"`
+	CompletionTokens int32   `json:"completion_tokens"`
+	TotalTokens      int32   `json:"total_tokens"`
+	CostUSD          float64 `json:"cost_usd,omitempty"`
+}
+
+// toOutputLine maps a provider result to the OpenAI batch output format
+func toOutputLine(model string, result domain.BatchItemResult, cost float64) outputLine {
+	line := outputLine{
+		ID:       "batch_req_" + uuid.New().String(),
+		CustomID: result.CustomID,
+	}
+	if result.Error != "" || result.Response == nil {
+		message := result.Error
+		if message == "" {
+			message = "no response returned by provider"
+		}
+		line.Error = &outputError{Code: "batch_request_failed", Message: message}
+		return line
+	}
+
+	resp := result.Response
+	msg := chatMessage{Role: "assistant", Content: resp.Content}
+	for _, tc := range resp.ToolCalls {
+		argsJSON, _ := json.Marshal(tc.Function.Arguments)
+		call := toolCall{ID: tc.ID, Type: "function"}
+		call.Function.Name = tc.Function.Name
+		call.Function.Arguments = string(argsJSON)
+		msg.ToolCalls = append(msg.ToolCalls, call)
+	}
+
+	reason := "stop"
+	switch resp.FinishReason {
+	case domain.FinishReasonToolCalls:
+		reason = "tool_calls"
+	case domain.FinishReasonLength:
+		reason = "length"
+	}
+
+	if resp.Model != "" {
+		model = resp.Model
+	}
+	body := chatCompletion{
+		ID:      "chatcmpl-" + uuid.New().String(),
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Model:   model,
+		Choices: []chatChoice{{Index: 0, Message: msg, FinishReason: reason}},
+	}
+	if resp.Usage != nil {
+		body.Usage = &completionUsage{
+			PromptTokens:     resp.Usage.PromptTokens,
+			CompletionTokens: resp.Usage.CompletionTokens,
+			TotalTokens:      resp.Usage.PromptTokens + resp.Usage.CompletionTokens,
+			CostUSD:          cost,
+		}
+	}
+
+	statusCode := result.StatusCode
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+	line.Response = &outputResponse{StatusCode: statusCode, RequestID: body.ID, Body: body}
+	return line
+}
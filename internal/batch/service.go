@@ -0,0 +1,414 @@
+// Package batch runs batch jobs through providers' native batch APIs.
+package batch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+	"modelgate/internal/pricing"
+
+	"github.com/google/uuid"
+)
+
+// Errors returned by the service. HTTP handlers map these to status codes.
+var (
+	ErrBatchNotFound       = errors.New("batch not found")
+	ErrInvalidBatch        = errors.New("invalid batch")
+	ErrBatchNotSupported   = errors.New("provider does not support native batches")
+	ErrBatchNotCancellable = errors.New("batch cannot be cancelled")
+)
+
+// Store is the persistence used by the service (implemented by postgres.TenantStore)
+type Store interface {
+	CreateBatch(ctx context.Context, batch *domain.Batch) error
+	UpdateBatch(ctx context.Context, batch *domain.Batch) error
+	GetBatch(ctx context.Context, id string) (*domain.Batch, error)
+	ListBatches(ctx context.Context, apiKeyID string, limit int) ([]*domain.Batch, error)
+	ListActiveBatches(ctx context.Context) ([]*domain.Batch, error)
+}
+
+// ClientResolver returns the provider client that serves a model (implemented by gateway.Service)
+type ClientResolver interface {
+	GetClientForModel(ctx context.Context, model string) (domain.LLMClient, error)
+}
+
+// Pricer returns a model's price, or nil when it is unknown. When the
+// ClientResolver implements it (gateway.Service does), batch items are priced
+// like regular requests.
+type Pricer interface {
+	PriceFor(ctx context.Context, model string) *domain.ModelPrice
+}
+
+// FileWriter stores batch output files (implemented by files.Service)
+type FileWriter interface {
+	CreateFile(ctx context.Context, meta domain.File, content io.Reader) (*domain.File, error)
+}
+
+// CreateRequest describes a new batch. Items must already be validated and policy-checked.
+type CreateRequest struct {
+	APIKeyID         string
+	Endpoint         string
+	InputFileID      string
+	CompletionWindow string
+	Metadata         map[string]any
+	Items            []domain.BatchItem
+}
+
+// Service submits batches to providers, polls them and maps results back
+type Service struct {
+	config  atomic.Pointer[config.Config]
+	store   Store
+	clients ClientResolver
+	files   FileWriter
+	usage   domain.UsageRepository
+}
+
+// NewService creates a new batch service
+func NewService(cfg *config.Config, store Store, clients ClientResolver, files FileWriter, usage domain.UsageRepository) *Service {
+	s := &Service{store: store, clients: clients, files: files, usage: usage}
+	s.config.Store(cfg)
+	return s
+}
+
+// SetConfig swaps in a reloaded configuration; a new poll interval applies after the next poll
+func (s *Service) SetConfig(cfg *config.Config) {
+	s.config.Store(cfg)
+}
+
+// Create records a batch and submits it to the provider serving its model.
+// All items must target the same model, since a provider batch is per-provider.
+func (s *Service) Create(ctx context.Context, req CreateRequest) (*domain.Batch, error) {
+	if len(req.Items) == 0 {
+		return nil, fmt.Errorf("%w: batch has no requests", ErrInvalidBatch)
+	}
+
+	model := req.Items[0].Request.Model
+	seen := make(map[string]bool, len(req.Items))
+	for i, item := range req.Items {
+		if item.CustomID == "" {
+			return nil, fmt.Errorf("%w: request %d has no custom_id", ErrInvalidBatch, i+1)
+		}
+		if seen[item.CustomID] {
+			return nil, fmt.Errorf("%w: duplicate custom_id %q", ErrInvalidBatch, item.CustomID)
+		}
+		seen[item.CustomID] = true
+		if item.Request.Model != model {
+			return nil, fmt.Errorf("%w: all requests must use the same model (got %s and %s)", ErrInvalidBatch, model, item.Request.Model)
+		}
+	}
+
+	providerType, ok := s.config.Load().GetProviderForModel(model)
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown provider for model %s", ErrInvalidBatch, model)
+	}
+	client, err := s.clients.GetClientForModel(ctx, model)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBatch, err)
+	}
+	batchClient, ok := client.(domain.BatchCapable)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrBatchNotSupported, providerType)
+	}
+
+	completionWindow := req.CompletionWindow
+	if completionWindow == "" {
+		completionWindow = "24h"
+	}
+	batch := &domain.Batch{
+		APIKeyID:         req.APIKeyID,
+		Endpoint:         req.Endpoint,
+		InputFileID:      req.InputFileID,
+		CompletionWindow: completionWindow,
+		Status:           domain.BatchStatusValidating,
+		Provider:         providerType,
+		Model:            model,
+		RequestCounts:    domain.BatchCounts{Total: len(req.Items)},
+		Metadata:         req.Metadata,
+	}
+	if err := s.store.CreateBatch(ctx, batch); err != nil {
+		return nil, fmt.Errorf("create batch: %w", err)
+	}
+
+	providerBatchID, err := batchClient.SubmitBatch(ctx, req.Items)
+	if err != nil {
+		slog.Error("Failed to submit batch to provider", "batch_id", batch.ID, "provider", providerType, "error", err)
+		now := time.Now()
+		batch.Status = domain.BatchStatusFailed
+		batch.Errors = err.Error()
+		batch.CompletedAt = &now
+	} else {
+		batch.Status = domain.BatchStatusInProgress
+		batch.ProviderBatchID = providerBatchID
+	}
+	if err := s.store.UpdateBatch(ctx, batch); err != nil {
+		return nil, fmt.Errorf("update batch: %w", err)
+	}
+	return batch, nil
+}
+
+// Get returns a batch
+func (s *Service) Get(ctx context.Context, id string) (*domain.Batch, error) {
+	batch, err := s.store.GetBatch(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("get batch: %w", err)
+	}
+	if batch == nil {
+		return nil, ErrBatchNotFound
+	}
+	return batch, nil
+}
+
+// List returns the most recent batches. If apiKeyID is set, only that key's batches are returned.
+func (s *Service) List(ctx context.Context, apiKeyID string, limit int) ([]*domain.Batch, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	return s.store.ListBatches(ctx, apiKeyID, limit)
+}
+
+// Cancel asks the provider to stop a running batch. The poller picks up the final state.
+func (s *Service) Cancel(ctx context.Context, id string) (*domain.Batch, error) {
+	batch, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if batch.Status != domain.BatchStatusInProgress && batch.Status != domain.BatchStatusFinalizing {
+		return nil, fmt.Errorf("%w: status is %s", ErrBatchNotCancellable, batch.Status)
+	}
+
+	batchClient, err := s.batchClient(ctx, batch)
+	if err != nil {
+		return nil, err
+	}
+	if err := batchClient.CancelBatch(ctx, batch.ProviderBatchID); err != nil {
+		return nil, fmt.Errorf("cancel provider batch: %w", err)
+	}
+
+	batch.Status = domain.BatchStatusCancelling
+	if err := s.store.UpdateBatch(ctx, batch); err != nil {
+		return nil, fmt.Errorf("update batch: %w", err)
+	}
+	return batch, nil
+}
+
+// Poll refreshes every active batch from its provider. Returns the number of batches that finished.
+func (s *Service) Poll(ctx context.Context) (int, error) {
+	batches, err := s.store.ListActiveBatches(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("list active batches: %w", err)
+	}
+
+	finished := 0
+	for _, batch := range batches {
+		done, err := s.refresh(ctx, batch)
+		if err != nil {
+			slog.Warn("Failed to refresh batch", "batch_id", batch.ID, "provider", batch.Provider, "error", err)
+			continue
+		}
+		if done {
+			finished++
+		}
+	}
+	return finished, nil
+}
+
+// StartPoller periodically polls active batches until ctx is cancelled
+func (s *Service) StartPoller(ctx context.Context) {
+	interval := s.pollInterval()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				n, err := s.Poll(ctx)
+				if err != nil {
+					slog.Error("Failed to poll batches", "error", err)
+				} else if n > 0 {
+					slog.Info("Batches finished", "count", n)
+				}
+				// Pick up a reloaded interval
+				if next := s.pollInterval(); next != interval {
+					interval = next
+					ticker.Reset(interval)
+				}
+			}
+		}
+	}()
+}
+
+func (s *Service) pollInterval() time.Duration {
+	if interval := s.config.Load().Batches.PollInterval; interval > 0 {
+		return interval
+	}
+	return time.Minute
+}
+
+// refresh updates one batch from the provider, collecting results once it has ended
+func (s *Service) refresh(ctx context.Context, batch *domain.Batch) (bool, error) {
+	batchClient, err := s.batchClient(ctx, batch)
+	if err != nil {
+		return false, err
+	}
+
+	status, err := batchClient.GetBatchStatus(ctx, batch.ProviderBatchID)
+	if err != nil {
+		return false, fmt.Errorf("get provider batch status: %w", err)
+	}
+	if status.RequestCounts.Total > 0 {
+		batch.RequestCounts = status.RequestCounts
+	}
+
+	if !status.Status.IsTerminal() {
+		// Keep our own cancelling state until the provider reports the batch has ended
+		if batch.Status != domain.BatchStatusCancelling {
+			batch.Status = status.Status
+		}
+		return false, s.store.UpdateBatch(ctx, batch)
+	}
+
+	if status.Status == domain.BatchStatusCompleted || status.Status == domain.BatchStatusCancelled {
+		results, err := batchClient.GetBatchResults(ctx, batch.ProviderBatchID)
+		if err != nil {
+			return false, fmt.Errorf("get provider batch results: %w", err)
+		}
+		if err := s.collectResults(ctx, batch, results, batchClient.BatchDiscount()); err != nil {
+			return false, err
+		}
+	}
+
+	now := time.Now()
+	batch.Status = status.Status
+	batch.CompletedAt = &now
+	return true, s.store.UpdateBatch(ctx, batch)
+}
+
+// collectResults writes the OpenAI-format output file and records discounted usage for each item
+func (s *Service) collectResults(ctx context.Context, batch *domain.Batch, results []domain.BatchItemResult, discount float64) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	counts := domain.BatchCounts{Total: batch.RequestCounts.Total}
+	price := s.price(ctx, batch)
+	if price == nil {
+		slog.Warn("Model price unknown, batch usage recorded without cost", "batch_id", batch.ID, "model", batch.Model)
+	}
+
+	for _, result := range results {
+		cost := itemCost(price, result.Response, discount)
+		if err := enc.Encode(toOutputLine(batch.Model, result, cost)); err != nil {
+			return fmt.Errorf("encode batch result: %w", err)
+		}
+
+		success := result.Error == "" && result.Response != nil
+		if success {
+			counts.Completed++
+		} else {
+			counts.Failed++
+		}
+		s.recordUsage(ctx, batch, result, cost, discount, success)
+	}
+	if counts.Total < counts.Completed+counts.Failed {
+		counts.Total = counts.Completed + counts.Failed
+	}
+	batch.RequestCounts = counts
+
+	file, err := s.files.CreateFile(ctx, domain.File{
+		APIKeyID: batch.APIKeyID,
+		Filename: fmt.Sprintf("batch_%s_output.jsonl", batch.ID),
+		Purpose:  "batch_output",
+		MimeType: "application/jsonl",
+	}, &buf)
+	if err != nil {
+		return fmt.Errorf("store batch output: %w", err)
+	}
+	batch.OutputFileID = file.ID
+	return nil
+}
+
+// price returns the batch model's current price: the gateway's when the
+// resolver prices requests, otherwise the configured price, then the bundled
+// pricing catalog. It returns nil when the price is unknown.
+func (s *Service) price(ctx context.Context, batch *domain.Batch) *domain.ModelPrice {
+	if pricer, ok := s.clients.(Pricer); ok {
+		return pricer.PriceFor(ctx, batch.Model)
+	}
+	if modelCfg, ok := s.config.Load().GetModel(batch.Model); ok && (modelCfg.InputCostPer1M > 0 || modelCfg.OutputCostPer1M > 0) {
+		return &domain.ModelPrice{
+			Provider:        batch.Provider,
+			ModelID:         batch.Model,
+			InputCostPer1M:  modelCfg.InputCostPer1M,
+			OutputCostPer1M: modelCfg.OutputCostPer1M,
+			Source:          domain.PriceSourceConfig,
+		}
+	}
+	var catalog *pricing.Service
+	return catalog.Price(ctx, batch.Provider, batch.Model, time.Now())
+}
+
+// itemCost prices a result at the model's price times the provider's batch
+// discount, or 0 when the price is unknown
+func itemCost(price *domain.ModelPrice, resp *domain.ChatResponse, discount float64) float64 {
+	if price == nil || resp == nil || resp.Usage == nil {
+		return 0
+	}
+	return price.Cost(int64(resp.Usage.PromptTokens), int64(resp.Usage.CompletionTokens)) * discount
+}
+
+func (s *Service) recordUsage(ctx context.Context, batch *domain.Batch, result domain.BatchItemResult, cost, discount float64, success bool) {
+	if s.usage == nil {
+		return
+	}
+
+	record := &domain.UsageRecord{
+		ID:        uuid.New().String(),
+		APIKeyID:  batch.APIKeyID,
+		RequestID: batch.ID + ":" + result.CustomID,
+		Model:     batch.Model,
+		Provider:  batch.Provider,
+		CostUSD:   cost,
+		Success:   success,
+		Metadata: map[string]any{
+			"batch_id":       batch.ID,
+			"custom_id":      result.CustomID,
+			"batch_discount": discount,
+		},
+		Timestamp: time.Now(),
+	}
+	if !success {
+		record.ErrorCode = "batch_request_failed"
+		record.ErrorMessage = result.Error
+	}
+	if result.Response != nil && result.Response.Usage != nil {
+		record.InputTokens = int64(result.Response.Usage.PromptTokens)
+		record.OutputTokens = int64(result.Response.Usage.CompletionTokens)
+		record.TotalTokens = record.InputTokens + record.OutputTokens
+	}
+
+	if err := s.usage.Record(ctx, record); err != nil {
+		slog.Warn("Failed to record batch usage", "batch_id", batch.ID, "custom_id", result.CustomID, "error", err)
+	}
+}
+
+func (s *Service) batchClient(ctx context.Context, batch *domain.Batch) (domain.BatchCapable, error) {
+	client, err := s.clients.GetClientForModel(ctx, batch.Model)
+	if err != nil {
+		return nil, fmt.Errorf("get client: %w", err)
+	}
+	batchClient, ok := client.(domain.BatchCapable)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrBatchNotSupported, batch.Provider)
+	}
+	return batchClient, nil
+}
@@ -0,0 +1,192 @@
+package batch
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"testing"
+
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+)
+
+// fakeClient is a batch-capable provider client for tests
+type fakeClient struct {
+	domain.LLMClient
+	status    *domain.ProviderBatchStatus
+	results   []domain.BatchItemResult
+	submitted []domain.BatchItem
+}
+
+func (c *fakeClient) SubmitBatch(_ context.Context, items []domain.BatchItem) (string, error) {
+	c.submitted = items
+	return "provider-batch-1", nil
+}
+
+func (c *fakeClient) GetBatchStatus(context.Context, string) (*domain.ProviderBatchStatus, error) {
+	return c.status, nil
+}
+
+func (c *fakeClient) GetBatchResults(context.Context, string) ([]domain.BatchItemResult, error) {
+	return c.results, nil
+}
+
+func (c *fakeClient) CancelBatch(context.Context, string) error { return nil }
+
+func (c *fakeClient) BatchDiscount() float64 { return 0.5 }
+
+type fakeResolver struct{ client domain.LLMClient }
+
+func (r fakeResolver) GetClientForModel(context.Context, string) (domain.LLMClient, error) {
+	return r.client, nil
+}
+
+// memStore is an in-memory Store for tests
+type memStore struct{ batches map[string]*domain.Batch }
+
+func (m *memStore) CreateBatch(_ context.Context, b *domain.Batch) error {
+	b.ID = "batch-1"
+	m.batches[b.ID] = b
+	return nil
+}
+
+func (m *memStore) UpdateBatch(_ context.Context, b *domain.Batch) error {
+	m.batches[b.ID] = b
+	return nil
+}
+
+func (m *memStore) GetBatch(_ context.Context, id string) (*domain.Batch, error) {
+	return m.batches[id], nil
+}
+
+func (m *memStore) ListBatches(context.Context, string, int) ([]*domain.Batch, error) {
+	return nil, nil
+}
+
+func (m *memStore) ListActiveBatches(context.Context) ([]*domain.Batch, error) {
+	var out []*domain.Batch
+	for _, b := range m.batches {
+		if !b.Status.IsTerminal() {
+			out = append(out, b)
+		}
+	}
+	return out, nil
+}
+
+type memFiles struct{ content []byte }
+
+func (f *memFiles) CreateFile(_ context.Context, meta domain.File, r io.Reader) (*domain.File, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	f.content = data
+	meta.ID = "file-out"
+	return &meta, nil
+}
+
+type memUsage struct {
+	domain.UsageRepository
+	records []*domain.UsageRecord
+}
+
+func (u *memUsage) Record(_ context.Context, r *domain.UsageRecord) error {
+	u.records = append(u.records, r)
+	return nil
+}
+
+func TestBatchLifecycle(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.Config{Models: map[string]config.ModelConfig{
+		"openai/gpt-4o": {Provider: "openai", InputCostPer1M: 2, OutputCostPer1M: 8},
+	}}
+	client := &fakeClient{}
+	store := &memStore{batches: make(map[string]*domain.Batch)}
+	out := &memFiles{}
+	usage := &memUsage{}
+	svc := NewService(cfg, store, fakeResolver{client}, out, usage)
+
+	items := []domain.BatchItem{
+		{CustomID: "a", Request: &domain.ChatRequest{Model: "openai/gpt-4o"}},
+		{CustomID: "b", Request: &domain.ChatRequest{Model: "openai/gpt-4o"}},
+	}
+	b, err := svc.Create(ctx, CreateRequest{Endpoint: "/v1/chat/completions", Items: items})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if b.Status != domain.BatchStatusInProgress || b.ProviderBatchID != "provider-batch-1" {
+		t.Fatalf("unexpected batch after submit: status=%s provider_batch_id=%s", b.Status, b.ProviderBatchID)
+	}
+
+	client.status = &domain.ProviderBatchStatus{Status: domain.BatchStatusCompleted}
+	client.results = []domain.BatchItemResult{
+		{CustomID: "a", StatusCode: 200, Response: &domain.ChatResponse{
+			Content: "hi",
+			Usage:   &domain.UsageEvent{PromptTokens: 1_000_000, CompletionTokens: 1_000_000},
+		}},
+		{CustomID: "b", Error: "overloaded"},
+	}
+	if n, err := svc.Poll(ctx); err != nil || n != 1 {
+		t.Fatalf("Poll = %d, %v; want 1, nil", n, err)
+	}
+
+	b = store.batches["batch-1"]
+	if b.Status != domain.BatchStatusCompleted || b.OutputFileID != "file-out" {
+		t.Errorf("status=%s output_file_id=%s; want completed, file-out", b.Status, b.OutputFileID)
+	}
+	if b.RequestCounts.Completed != 1 || b.RequestCounts.Failed != 1 {
+		t.Errorf("request counts = %+v", b.RequestCounts)
+	}
+
+	var lines []outputLine
+	scanner := bufio.NewScanner(bytes.NewReader(out.content))
+	for scanner.Scan() {
+		var line outputLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("invalid output line: %v", err)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) != 2 || lines[0].Response == nil || lines[0].Response.Body.Choices[0].Message.Content != "hi" || lines[1].Error == nil {
+		t.Fatalf("unexpected output lines: %+v", lines)
+	}
+
+	// $2 input + $8 output at the 50% batch discount
+	if len(usage.records) != 2 || math.Abs(usage.records[0].CostUSD-5) > 1e-9 {
+		t.Errorf("usage records = %d, first cost = %v; want 2, 5", len(usage.records), usage.records[0].CostUSD)
+	}
+}
+
+func TestCreateRejectsMixedModels(t *testing.T) {
+	svc := NewService(&config.Config{}, &memStore{batches: make(map[string]*domain.Batch)}, fakeResolver{&fakeClient{}}, &memFiles{}, nil)
+	_, err := svc.Create(context.Background(), CreateRequest{Items: []domain.BatchItem{
+		{CustomID: "a", Request: &domain.ChatRequest{Model: "openai/gpt-4o"}},
+		{CustomID: "b", Request: &domain.ChatRequest{Model: "anthropic/claude-sonnet-4"}},
+	}})
+	if err == nil {
+		t.Fatal("expected mixed-model batch to be rejected")
+	}
+}
+
+func TestPriceFallsBackToCatalog(t *testing.T) {
+	svc := NewService(&config.Config{}, &memStore{batches: make(map[string]*domain.Batch)}, fakeResolver{&fakeClient{}}, &memFiles{}, nil)
+	ctx := context.Background()
+
+	// Models missing from the config are priced from the bundled catalog
+	price := svc.price(ctx, &domain.Batch{Provider: domain.ProviderOpenAI, Model: "openai/gpt-4o"})
+	if price == nil || price.Source != domain.PriceSourceCatalog {
+		t.Fatalf("expected a catalog price, got %+v", price)
+	}
+	resp := &domain.ChatResponse{Usage: &domain.UsageEvent{PromptTokens: 1_000_000, CompletionTokens: 1_000_000}}
+	if cost := itemCost(price, resp, 0.5); math.Abs(cost-(price.InputCostPer1M+price.OutputCostPer1M)/2) > 1e-9 {
+		t.Errorf("cost = %v", cost)
+	}
+
+	// Unknown prices are reported as such rather than as free
+	if price := svc.price(ctx, &domain.Batch{Provider: domain.ProviderOpenAI, Model: "openai/not-a-model"}); price != nil {
+		t.Errorf("expected no price, got %+v", price)
+	}
+}
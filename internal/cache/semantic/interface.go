@@ -17,6 +17,14 @@ type CacheService interface {
 		config domain.CachingPolicy,
 	) (*domain.ChatResponse, bool, error)
 
+	// GetWithDetails is Get that also reports the similarity of the match
+	GetWithDetails(
+		ctx context.Context,
+		roleID, model string,
+		messages []domain.Message,
+		config domain.CachingPolicy,
+	) (*CacheResult, error)
+
 	// Set stores a response in the cache
 	// roleID: role for cache isolation
 	Set(
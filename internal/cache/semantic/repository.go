@@ -4,9 +4,14 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/pgvector/pgvector-go"
+	"modelgate/internal/cache/embedding"
+	"modelgate/internal/crypto"
 	"modelgate/internal/domain"
 )
 
@@ -30,9 +35,22 @@ type CacheEntry struct {
 	LastHitAt       time.Time
 }
 
+// Prompt returns the last user message of the cached request, which is what
+// entries are matched on
+func (e *CacheEntry) Prompt() string {
+	var messages []domain.Message
+	if err := json.Unmarshal(e.RequestContent, &messages); err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(embedding.NormalizePrompt(messages), "user:")
+}
+
 // Repository handles semantic cache database operations
 type Repository struct {
 	db *sql.DB
+
+	// cipher encrypts request and response content at rest; nil stores it as is
+	cipher *crypto.ContentCipher
 }
 
 // NewRepository creates a new semantic cache repository
@@ -40,6 +58,37 @@ func NewRepository(db *sql.DB) *Repository {
 	return &Repository{db: db}
 }
 
+// SetCipher sets the tenant content cipher used to encrypt cached content
+func (r *Repository) SetCipher(cipher *crypto.ContentCipher) {
+	r.cipher = cipher
+}
+
+// seal encrypts cached content into a JSON string so it still fits the JSONB
+// columns
+func (r *Repository) seal(content []byte) ([]byte, error) {
+	if r.cipher == nil {
+		return content, nil
+	}
+	sealed, err := r.cipher.Seal(content)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(sealed)
+}
+
+// open decrypts content written by seal. Content cached before encryption was
+// enabled is returned unchanged.
+func (r *Repository) open(content []byte) ([]byte, error) {
+	var sealed string
+	if len(content) == 0 || content[0] != '"' || json.Unmarshal(content, &sealed) != nil || !crypto.IsSealed(sealed) {
+		return content, nil
+	}
+	if r.cipher == nil {
+		return nil, fmt.Errorf("cached content is encrypted but no encryption key is configured")
+	}
+	return r.cipher.Open(sealed)
+}
+
 // GetByHash attempts exact match by hash (fast path)
 // roleID can be empty string to match any role, or specific role for isolation
 func (r *Repository) GetByHash(ctx context.Context, roleID, model, requestHash string) (*CacheEntry, error) {
@@ -92,6 +141,9 @@ func (r *Repository) GetByHash(ctx context.Context, roleID, model, requestHash s
 	if err != nil {
 		return nil, err
 	}
+	if entry.ResponseContent, err = r.open(entry.ResponseContent); err != nil {
+		return nil, nil // Unreadable entries are a miss
+	}
 
 	if roleIDNull.Valid {
 		entry.RoleID = roleIDNull.String
@@ -109,7 +161,13 @@ func (r *Repository) GetByHash(ctx context.Context, roleID, model, requestHash s
 	return &entry, nil
 }
 
-// SearchBySimilarity uses pgvector similarity search
+// filteredSearchCandidates is the HNSW candidate list (hnsw.ef_search) for
+// cache lookups, wider than pgvector's default of 40
+const filteredSearchCandidates = 400
+
+// SearchBySimilarity uses pgvector similarity search. The nearest entry is
+// found by ordering on the distance alone so the HNSW index can serve the
+// query; the threshold is checked on the result.
 func (r *Repository) SearchBySimilarity(
 	ctx context.Context,
 	roleID, model string,
@@ -130,11 +188,10 @@ func (r *Repository) SearchBySimilarity(
 			  AND role_id = $3
 			  AND expires_at > NOW()
 			  AND embedding IS NOT NULL
-			  AND 1 - (embedding <=> $1::vector) >= $4
-			ORDER BY similarity DESC
+			ORDER BY embedding <=> $1::vector
 			LIMIT 1
 		`
-		args = []interface{}{embedding, model, roleID, similarityThreshold}
+		args = []interface{}{embedding, model, roleID}
 	} else {
 		query = `
 			SELECT
@@ -145,11 +202,10 @@ func (r *Repository) SearchBySimilarity(
 			WHERE model = $2
 			  AND expires_at > NOW()
 			  AND embedding IS NOT NULL
-			  AND 1 - (embedding <=> $1::vector) >= $3
-			ORDER BY similarity DESC
+			ORDER BY embedding <=> $1::vector
 			LIMIT 1
 		`
-		args = []interface{}{embedding, model, similarityThreshold}
+		args = []interface{}{embedding, model}
 	}
 
 	var entry CacheEntry
@@ -160,7 +216,19 @@ func (r *Repository) SearchBySimilarity(
 	var latencyMsNull sql.NullInt64
 	var providerNull sql.NullString
 
-	err := r.db.QueryRowContext(ctx, query, args...).Scan(
+	// The model and role filters apply to the entries the HNSW index returns,
+	// so widen its candidate list to still find a match when other models and
+	// roles fill the cache
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, 0, nil
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, `SELECT set_config('hnsw.ef_search', $1, true)`, strconv.Itoa(filteredSearchCandidates)); err != nil {
+		return nil, 0, nil
+	}
+
+	err = tx.QueryRowContext(ctx, query, args...).Scan(
 		&entry.ID, &roleIDNull, &entry.ResponseContent, &entry.InputTokens, &entry.OutputTokens,
 		&entry.CostUSD, &latencyMsNull, &providerNull, &entry.HitCount, &entry.CreatedAt, &entry.ExpiresAt,
 		&similarity,
@@ -174,6 +242,13 @@ func (r *Repository) SearchBySimilarity(
 		return nil, 0, nil
 	}
 
+	if similarity < similarityThreshold {
+		return nil, 0, nil // Nearest entry isn't close enough
+	}
+	if entry.ResponseContent, err = r.open(entry.ResponseContent); err != nil {
+		return nil, 0, nil // Unreadable entries are a miss
+	}
+
 	if roleIDNull.Valid {
 		entry.RoleID = roleIDNull.String
 	}
@@ -192,6 +267,15 @@ func (r *Repository) SearchBySimilarity(
 
 // Set stores a new cache entry with optional embedding
 func (r *Repository) Set(ctx context.Context, entry *CacheEntry) error {
+	requestContent, err := r.seal(entry.RequestContent)
+	if err != nil {
+		return fmt.Errorf("encrypt cached request: %w", err)
+	}
+	responseContent, err := r.seal(entry.ResponseContent)
+	if err != nil {
+		return fmt.Errorf("encrypt cached response: %w", err)
+	}
+
 	// First try with embedding if available
 	if len(entry.Embedding.Slice()) > 0 {
 		query := `
@@ -217,8 +301,8 @@ func (r *Repository) Set(ctx context.Context, entry *CacheEntry) error {
 		}
 
 		_, err := r.db.ExecContext(ctx, query,
-			roleID, entry.Model, entry.RequestHash, entry.RequestContent,
-			entry.ResponseContent, entry.Embedding, entry.InputTokens, entry.OutputTokens,
+			roleID, entry.Model, entry.RequestHash, requestContent,
+			responseContent, entry.Embedding, entry.InputTokens, entry.OutputTokens,
 			entry.CostUSD, entry.LatencyMs, entry.Provider, entry.ExpiresAt,
 		)
 
@@ -250,9 +334,9 @@ func (r *Repository) Set(ctx context.Context, entry *CacheEntry) error {
 		roleID = entry.RoleID
 	}
 
-	_, err := r.db.ExecContext(ctx, query,
-		roleID, entry.Model, entry.RequestHash, entry.RequestContent,
-		entry.ResponseContent, entry.InputTokens, entry.OutputTokens,
+	_, err = r.db.ExecContext(ctx, query,
+		roleID, entry.Model, entry.RequestHash, requestContent,
+		responseContent, entry.InputTokens, entry.OutputTokens,
 		entry.CostUSD, entry.LatencyMs, entry.Provider, entry.ExpiresAt,
 	)
 
@@ -370,3 +454,115 @@ func (r *Repository) Count(ctx context.Context) (int64, error) {
 	err := r.db.QueryRowContext(ctx, query).Scan(&count)
 	return count, err
 }
+
+// Filter selects cache entries to browse or invalidate. Set fields are
+// combined with AND; an empty filter matches every entry.
+type Filter struct {
+	RoleID         string
+	Model          string
+	CreatedBefore  time.Time // Zero for any age
+	PromptContains string    // Case-insensitive match on the cached request; never matches encrypted entries
+	SimilarTo      string    // Prompt text; matches entries whose prompt embedding is at least MinSimilarity similar
+	MinSimilarity  float64
+}
+
+// where builds the filter's conditions. vec is SimilarTo's embedding.
+func (f Filter) where(vec *pgvector.Vector) (string, []interface{}) {
+	var conds []string
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if f.RoleID != "" {
+		conds = append(conds, "role_id = "+arg(f.RoleID))
+	}
+	if f.Model != "" {
+		conds = append(conds, "model = "+arg(f.Model))
+	}
+	if !f.CreatedBefore.IsZero() {
+		conds = append(conds, "created_at < "+arg(f.CreatedBefore))
+	}
+	if f.PromptContains != "" {
+		escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(f.PromptContains)
+		conds = append(conds, "request_content::text ILIKE "+arg("%"+escaped+"%"))
+	}
+	if vec != nil {
+		conds = append(conds, fmt.Sprintf("embedding IS NOT NULL AND 1 - (embedding <=> %s::vector) >= %s",
+			arg(*vec), arg(f.MinSimilarity)))
+	}
+	if len(conds) == 0 {
+		return "TRUE", args
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+// List returns unexpired entries matching the filter, most recent first, and
+// how many match in total
+func (r *Repository) List(ctx context.Context, f Filter, vec *pgvector.Vector, limit, offset int) ([]*CacheEntry, int64, error) {
+	where, args := f.where(vec)
+	where += " AND expires_at > NOW()"
+
+	var total int64
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM semantic_cache WHERE `+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, limit, offset)
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT id, role_id, model, request_content, input_tokens, output_tokens, cost_usd,
+		       latency_ms, provider, hit_count, created_at, expires_at, last_hit_at
+		FROM semantic_cache
+		WHERE %s
+		ORDER BY created_at DESC
+		LIMIT $%d OFFSET $%d
+	`, where, len(args)-1, len(args)), args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var entries []*CacheEntry
+	for rows.Next() {
+		var entry CacheEntry
+		var roleID, provider sql.NullString
+		var latencyMs sql.NullInt64
+		var lastHitAt sql.NullTime
+		if err := rows.Scan(&entry.ID, &roleID, &entry.Model, &entry.RequestContent, &entry.InputTokens,
+			&entry.OutputTokens, &entry.CostUSD, &latencyMs, &provider, &entry.HitCount,
+			&entry.CreatedAt, &entry.ExpiresAt, &lastHitAt); err != nil {
+			return nil, 0, err
+		}
+		entry.RoleID = roleID.String
+		entry.Provider = provider.String
+		entry.LatencyMs = int(latencyMs.Int64)
+		entry.LastHitAt = lastHitAt.Time
+		if entry.RequestContent, err = r.open(entry.RequestContent); err != nil {
+			entry.RequestContent = nil // Listed without its prompt
+		}
+		entries = append(entries, &entry)
+	}
+	return entries, total, rows.Err()
+}
+
+// DeleteMatching removes the entries matching the filter and returns how many
+// were removed
+func (r *Repository) DeleteMatching(ctx context.Context, f Filter, vec *pgvector.Vector) (int64, error) {
+	where, args := f.where(vec)
+	res, err := r.db.ExecContext(ctx, `DELETE FROM semantic_cache WHERE `+where, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// DeleteByID removes one cache entry, returning false if it did not exist
+func (r *Repository) DeleteByID(ctx context.Context, id string) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM semantic_cache WHERE id = $1`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
@@ -2,8 +2,10 @@ package semantic
 
 import (
 	"context"
+	"errors"
 	"time"
 
+	"github.com/pgvector/pgvector-go"
 	"modelgate/internal/cache/embedding"
 	"modelgate/internal/domain"
 )
@@ -26,8 +28,10 @@ func NewService(repo *Repository, embeddingSvc *embedding.EmbeddingService) *Ser
 type CacheResult struct {
 	Response   *domain.ChatResponse
 	Hit        bool
-	Similarity float64 // Similarity score if semantic match (1.0 for exact match)
-	LatencyMs  int     // Original request latency (for stats)
+	Similarity float64   // Similarity score if semantic match (1.0 for exact match)
+	Exact      bool      // The prompt matched by hash rather than by similarity
+	StoredAt   time.Time // When the cached response was stored
+	LatencyMs  int       // Original request latency (for stats)
 }
 
 // Get attempts to retrieve a cached response
@@ -83,6 +87,8 @@ func (s *Service) GetWithDetails(
 		result.Response = response
 		result.Hit = true
 		result.Similarity = 1.0 // Exact match
+		result.Exact = true
+		result.StoredAt = entry.CreatedAt
 		result.LatencyMs = entry.LatencyMs
 		return result, nil
 	}
@@ -114,6 +120,7 @@ func (s *Service) GetWithDetails(
 			result.Response = response
 			result.Hit = true
 			result.Similarity = similarity
+			result.StoredAt = entry.CreatedAt
 			result.LatencyMs = entry.LatencyMs
 			return result, nil
 		}
@@ -297,6 +304,60 @@ func (s *Service) InvalidateByRole(ctx context.Context, roleID string) error {
 	return s.repo.DeleteByRole(ctx, roleID)
 }
 
+// defaultMinSimilarity is the similarity a Filter's SimilarTo matches at when
+// it sets none
+const defaultMinSimilarity = 0.9
+
+// ErrSimilarityUnavailable is returned when matching by similarity without an
+// embedding service
+var ErrSimilarityUnavailable = errors.New("prompt similarity requires an embedding service")
+
+// filterEmbedding embeds a filter's SimilarTo text the way cached prompts are
+// embedded
+func (s *Service) filterEmbedding(ctx context.Context, f *Filter) (*pgvector.Vector, error) {
+	if f.SimilarTo == "" {
+		return nil, nil
+	}
+	if s.embedding == nil {
+		return nil, ErrSimilarityUnavailable
+	}
+	if f.MinSimilarity <= 0 {
+		f.MinSimilarity = defaultMinSimilarity
+	}
+	vec, err := s.embedding.GenerateEmbedding(ctx, embedding.NormalizePrompt([]domain.Message{
+		{Role: "user", Content: []domain.ContentBlock{{Type: "text", Text: f.SimilarTo}}},
+	}))
+	if err != nil {
+		return nil, err
+	}
+	return &vec, nil
+}
+
+// List returns unexpired cache entries matching the filter, most recent
+// first, and how many match in total
+func (s *Service) List(ctx context.Context, f Filter, limit, offset int) ([]*CacheEntry, int64, error) {
+	vec, err := s.filterEmbedding(ctx, &f)
+	if err != nil {
+		return nil, 0, err
+	}
+	return s.repo.List(ctx, f, vec, limit, offset)
+}
+
+// InvalidateMatching removes the cache entries matching the filter and
+// returns how many were removed
+func (s *Service) InvalidateMatching(ctx context.Context, f Filter) (int64, error) {
+	vec, err := s.filterEmbedding(ctx, &f)
+	if err != nil {
+		return 0, err
+	}
+	return s.repo.DeleteMatching(ctx, f, vec)
+}
+
+// InvalidateByID removes one cache entry, returning false if it did not exist
+func (s *Service) InvalidateByID(ctx context.Context, id string) (bool, error) {
+	return s.repo.DeleteByID(ctx, id)
+}
+
 // Count returns the number of active cache entries
 func (s *Service) Count(ctx context.Context) (int64, error) {
 	return s.repo.Count(ctx)
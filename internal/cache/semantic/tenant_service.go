@@ -5,6 +5,7 @@ import (
 	"database/sql"
 
 	"modelgate/internal/cache/embedding"
+	"modelgate/internal/crypto"
 	"modelgate/internal/domain"
 )
 
@@ -31,6 +32,12 @@ func NewTenantAwareService(
 	}
 }
 
+// SetContentCipher encrypts cached requests and responses at rest with the
+// tenant's data key
+func (s *TenantAwareService) SetContentCipher(cipher *crypto.ContentCipher) {
+	s.service.repo.SetCipher(cipher)
+}
+
 // Get attempts to retrieve a cached response
 // roleID: role for cache isolation
 func (s *TenantAwareService) Get(
@@ -42,6 +49,16 @@ func (s *TenantAwareService) Get(
 	return s.service.Get(ctx, roleID, model, messages, config)
 }
 
+// GetWithDetails attempts to retrieve a cached response with its similarity score
+func (s *TenantAwareService) GetWithDetails(
+	ctx context.Context,
+	roleID, model string,
+	messages []domain.Message,
+	config domain.CachingPolicy,
+) (*CacheResult, error) {
+	return s.service.GetWithDetails(ctx, roleID, model, messages, config)
+}
+
 // Set stores a response in the cache
 func (s *TenantAwareService) Set(
 	ctx context.Context,
@@ -87,6 +104,21 @@ func (s *TenantAwareService) InvalidateByRole(ctx context.Context, roleID string
 	return s.service.InvalidateByRole(ctx, roleID)
 }
 
+// List returns unexpired cache entries matching the filter
+func (s *TenantAwareService) List(ctx context.Context, f Filter, limit, offset int) ([]*CacheEntry, int64, error) {
+	return s.service.List(ctx, f, limit, offset)
+}
+
+// InvalidateMatching removes the cache entries matching the filter
+func (s *TenantAwareService) InvalidateMatching(ctx context.Context, f Filter) (int64, error) {
+	return s.service.InvalidateMatching(ctx, f)
+}
+
+// InvalidateByID removes one cache entry
+func (s *TenantAwareService) InvalidateByID(ctx context.Context, id string) (bool, error) {
+	return s.service.InvalidateByID(ctx, id)
+}
+
 // Count returns the number of active cache entries
 func (s *TenantAwareService) Count(ctx context.Context) (int64, error) {
 	return s.service.Count(ctx)
@@ -15,15 +15,325 @@ import (
 
 // Config is the root configuration structure
 type Config struct {
-	Server    ServerConfig           `toml:"server"`
-	Telemetry TelemetryConfig        `toml:"telemetry"`
-	Database  DatabaseConfig         `toml:"database"`
-	Providers ProvidersConfig        `toml:"providers"`
-	Models    map[string]ModelConfig `toml:"models"`
-	Aliases   map[string]string      `toml:"aliases"`
-	Policies  PolicyConfig           `toml:"policies"`
-	Security  SecurityConfig         `toml:"security"`
-	Embedder  EmbedderConfig         `toml:"embedder"`
+	Server        ServerConfig           `toml:"server"`
+	Telemetry     TelemetryConfig        `toml:"telemetry"`
+	Database      DatabaseConfig         `toml:"database"`
+	Providers     ProvidersConfig        `toml:"providers"`
+	Models        map[string]ModelConfig `toml:"models"`
+	Aliases       map[string]string      `toml:"aliases"`
+	Policies      PolicyConfig           `toml:"policies"`
+	Security      SecurityConfig         `toml:"security"`
+	Embedder      EmbedderConfig         `toml:"embedder"`
+	Files         FilesConfig            `toml:"files"`
+	Batches       BatchesConfig          `toml:"batches"`
+	FineTuning    FineTuningConfig       `toml:"fine_tuning"`
+	Responses     ResponsesConfig        `toml:"responses"`
+	Retention     RetentionConfig        `toml:"retention"`
+	Pricing       PricingConfig          `toml:"pricing"`
+	Export        UsageExportConfig      `toml:"usage_export"`
+	Threads       ThreadsConfig          `toml:"threads"`
+	AuditExport   AuditExportConfig      `toml:"audit_export"`
+	Replay        ReplayConfig           `toml:"replay"`
+	Email         EmailConfig            `toml:"email"`
+	Forecast      ForecastConfig         `toml:"forecast"`
+	Registration  RegistrationConfig     `toml:"registration"`
+	StickyRouting StickyRoutingConfig    `toml:"sticky_routing"`
+	Idempotency   IdempotencyConfig      `toml:"idempotency"`
+	ToolApproval  ToolApprovalConfig     `toml:"tool_approval"`
+	Discovery     ModelDiscoveryConfig   `toml:"model_discovery"`
+	DataAudit     DataPlaneAuditConfig   `toml:"data_plane_audit"`
+	EventStream   EventStreamConfig      `toml:"event_stream"`
+	Attestation   AttestationConfig      `toml:"attestation"`
+	Warmup        ConnectionWarmupConfig `toml:"connection_warmup"`
+	Deprecation   ModelDeprecationConfig `toml:"model_deprecation"`
+	Domains       CustomDomainsConfig    `toml:"custom_domains"`
+	Documents     DocumentsConfig        `toml:"documents"`
+	GraphQL       GraphQLConfig          `toml:"graphql"`
+	Embeddings    EmbeddingRoutingConfig `toml:"embedding_routing"`
+	HealthHistory HealthHistoryConfig    `toml:"health_history"`
+	Passwords     PasswordConfig         `toml:"passwords"`
+	TwoFactor     TwoFactorConfig        `toml:"two_factor"`
+}
+
+// FilesConfig contains settings for file uploads
+type FilesConfig struct {
+	StorageDir      string        `toml:"storage_dir"`      // Local directory for parts and stitched files
+	MaxFileSize     int64         `toml:"max_file_size"`    // Maximum total size of an upload
+	MaxPartSize     int64         `toml:"max_part_size"`    // Maximum size of a single part
+	UploadTTL       time.Duration `toml:"upload_ttl"`       // How long an incomplete upload may stay open
+	CleanupInterval time.Duration `toml:"cleanup_interval"` // How often abandoned uploads are removed
+
+	Backend       string            `toml:"backend"`         // Where completed files are kept: "local" (default) or "object_store"
+	ObjectStore   ObjectStoreConfig `toml:"object_store"`    // Destination of the object_store backend
+	Retention     time.Duration     `toml:"retention"`       // Files uploaded via /v1/files are deleted after this long; 0 keeps them
+	MaxInlineSize int64             `toml:"max_inline_size"` // Largest stored file sent inline with a chat request
+}
+
+// ThreadsConfig contains settings for stored conversation threads
+type ThreadsConfig struct {
+	MaxMessages     int           `toml:"max_messages"`     // Maximum messages kept in a thread
+	MaxBytes        int64         `toml:"max_bytes"`        // Maximum total size of a thread's stored messages
+	TTL             time.Duration `toml:"ttl"`              // Threads expire after this long without a new message
+	CleanupInterval time.Duration `toml:"cleanup_interval"` // How often expired threads are deleted
+}
+
+// BatchesConfig contains settings for provider-native batch jobs
+type BatchesConfig struct {
+	PollInterval time.Duration `toml:"poll_interval"` // How often running batches are checked with the provider
+}
+
+// FineTuningConfig contains settings for fine-tuning jobs run by providers
+type FineTuningConfig struct {
+	PollInterval time.Duration `toml:"poll_interval"` // How often running jobs are checked with the provider
+}
+
+// ResponsesConfig contains settings for structured outputs (/v1/responses)
+type ResponsesConfig struct {
+	ValidationRetries int    `toml:"validation_retries"` // Times output failing schema validation is sent back for repair
+	RepairModel       string `toml:"repair_model"`       // Model for the last repair attempt; empty keeps the request's model
+}
+
+// ForecastConfig contains settings for month-end spend forecasts and the
+// alerts sent when a role is forecast to exceed its monthly budget
+type ForecastConfig struct {
+	AlertsEnabled bool          `toml:"alerts_enabled"`
+	CheckInterval time.Duration `toml:"check_interval"` // How often forecasts are checked against budgets
+}
+
+// RegistrationConfig contains settings for self-service registration requests
+type RegistrationConfig struct {
+	Enabled       bool     `toml:"enabled"`        // Accept registration requests from unauthenticated users
+	NotifyEmails  []string `toml:"notify_emails"`  // Notified of new requests; empty notifies every admin
+	NotifyWebhook string   `toml:"notify_webhook"` // Receives submitted, approved and rejected events
+	MaxPending    int      `toml:"max_pending"`    // Requests awaiting review before new ones are refused; 0 for no limit
+	DefaultRole   string   `toml:"default_role"`   // Role of approved users unless the admin picks another
+}
+
+// StickyRoutingConfig contains settings for conversation affinity: when a
+// role routes intelligently, the turns of a conversation stay on the target
+// picked for its first turn until that target fails
+type StickyRoutingConfig struct {
+	Enabled          bool          `toml:"enabled"`
+	TTL              time.Duration `toml:"ttl"`               // A conversation is unpinned this long after its last turn
+	MaxConversations int           `toml:"max_conversations"` // Pinned conversations kept per instance; 0 for no limit
+}
+
+// ToolApprovalConfig contains settings for the MCP tool approval queue
+type ToolApprovalConfig struct {
+	Enabled       bool     `toml:"enabled"`        // Queue first calls to unpermitted tools for review instead of denying them outright
+	NotifyEmails  []string `toml:"notify_emails"`  // Notified of new requests; empty notifies every admin
+	NotifyWebhook string   `toml:"notify_webhook"` // Receives requested, approved and denied events
+}
+
+// ModelDiscoveryConfig contains settings for the background job that lists
+// every enabled provider's models into the model catalog
+type ModelDiscoveryConfig struct {
+	Enabled       bool          `toml:"enabled"`
+	Interval      time.Duration `toml:"interval"`       // Time between runs; the first runs at startup
+	RemoveAfter   time.Duration `toml:"remove_after"`   // A model the provider stopped listing is deprecated, then removed after this long
+	NotifyWebhook string        `toml:"notify_webhook"` // Receives a report of each run that added, deprecated or removed models
+}
+
+// DataPlaneAuditConfig contains the delivery settings of data-plane audit
+// events. Whether events are written, and where, is set per tenant.
+type DataPlaneAuditConfig struct {
+	BufferSize      int           `toml:"buffer_size"`      // Events waiting to be written; further events are dropped
+	BatchSize       int           `toml:"batch_size"`       // Events written or posted at once
+	FlushInterval   time.Duration `toml:"flush_interval"`   // Longest an event waits before it is written
+	RetentionMonths int           `toml:"retention_months"` // Monthly partitions older than this are dropped; 0 keeps them
+}
+
+// EventStreamConfig contains settings for publishing usage records, policy
+// violations and audit events to Kafka or NATS
+type EventStreamConfig struct {
+	Enabled      bool              `toml:"enabled"`
+	Broker       string            `toml:"broker"`        // kafka or nats
+	Brokers      []string          `toml:"brokers"`       // Kafka bootstrap servers
+	NATSURL      string            `toml:"nats_url"`      // NATS server URL; the subjects must belong to a JetStream stream
+	Format       string            `toml:"format"`        // json or protobuf
+	Events       []string          `toml:"events"`        // Event types to publish: usage, policy_violation, audit
+	Topics       map[string]string `toml:"topics"`        // Kafka topic or NATS subject per event type; defaults to modelgate.<type>
+	BatchSize    int               `toml:"batch_size"`    // Events published at once
+	PollInterval time.Duration     `toml:"poll_interval"` // How often the outbox is checked for new events
+	Retention    time.Duration     `toml:"retention"`     // Published events are deleted from the outbox after this; 0 keeps them
+}
+
+// AttestationConfig contains settings for signed response attestations
+type AttestationConfig struct {
+	Enabled     bool          `toml:"enabled"`
+	RotateAfter time.Duration `toml:"rotate_after"` // The signing key is replaced once this old; 0 rotates only on request
+	Retention   time.Duration `toml:"retention"`    // Stored attestations are deleted after this; 0 keeps them
+}
+
+// HealthHistoryConfig contains settings for the provider health history and
+// SLA reports
+type HealthHistoryConfig struct {
+	Enabled       bool          `toml:"enabled"`
+	Bucket        time.Duration `toml:"bucket"`         // Width of a sample; the timeline can't be finer
+	Retention     time.Duration `toml:"retention"`      // Samples are deleted after this; 0 keeps them
+	SLATarget     float64       `toml:"sla_target"`     // Percentage of availability and uptime reports are held to
+	DegradedBelow float64       `toml:"degraded_below"` // A bucket with a lower share of successful requests counts as downtime
+}
+
+// PasswordConfig contains settings for dashboard user passwords: how they are
+// hashed, the rules they must follow and account lockout
+type PasswordConfig struct {
+	Algorithm          string        `toml:"algorithm"`          // "bcrypt" or "argon2id"; other hashes are replaced at the next login
+	BcryptCost         int           `toml:"bcrypt_cost"`        // bcrypt work factor
+	Argon2Memory       uint32        `toml:"argon2_memory"`      // argon2id memory in KiB
+	Argon2Iterations   uint32        `toml:"argon2_iterations"`  // argon2id passes over the memory
+	Argon2Parallelism  uint8         `toml:"argon2_parallelism"` // argon2id lanes
+	MinLength          int           `toml:"min_length"`
+	MinCharClasses     int           `toml:"min_char_classes"`     // Of lowercase, uppercase, digits and symbols; 0 doesn't check
+	MaxAge             time.Duration `toml:"max_age"`              // Passwords must be changed once this old; 0 never expires them
+	History            int           `toml:"history"`              // A new password can't be one of the last N; 0 allows reuse
+	LockoutThreshold   int           `toml:"lockout_threshold"`    // Failed logins in a row that lock the account; 0 disables lockout
+	LockoutDuration    time.Duration `toml:"lockout_duration"`     // The first lockout; each one after doubles it
+	MaxLockoutDuration time.Duration `toml:"max_lockout_duration"` // Lockouts grow up to this
+}
+
+// TwoFactorConfig contains settings for TOTP two-factor authentication. Which
+// users must enroll is a tenant setting.
+type TwoFactorConfig struct {
+	Issuer      string `toml:"issuer"`       // Shown for the account in authenticator apps
+	Skew        int    `toml:"skew"`         // 30 second steps a code is accepted before or after its own, for clock drift
+	BackupCodes int    `toml:"backup_codes"` // Single-use codes handed out at enrollment
+}
+
+// ConnectionWarmupConfig contains settings for opening provider connections
+// ahead of the first request
+type ConnectionWarmupConfig struct {
+	Enabled      bool          `toml:"enabled"`       // Open connections at startup and when provider settings change
+	Connections  int           `toml:"connections"`   // Connections opened per provider client, capped at its max_idle_connections
+	PingInterval time.Duration `toml:"ping_interval"` // How often idle connections are used so they stay open; 0 disables the pinger
+}
+
+// ModelDeprecationConfig contains settings for model sunset alerts
+type ModelDeprecationConfig struct {
+	AlertBefore   time.Duration `toml:"alert_before"`   // Admins are alerted this long before a model's sunset; 0 disables alerts
+	CheckInterval time.Duration `toml:"check_interval"` // How often upcoming sunsets are checked
+	NotifyEmails  []string      `toml:"notify_emails"`  // Alerted about upcoming sunsets; empty alerts every admin
+	NotifyWebhook string        `toml:"notify_webhook"` // Receives a model.sunset_upcoming event per alert
+}
+
+// CustomDomainsConfig contains settings for serving tenants on their own
+// hostnames
+type CustomDomainsConfig struct {
+	Enabled       bool     `toml:"enabled"`
+	TerminateTLS  bool     `toml:"terminate_tls"`  // Serve HTTPS with each domain's certificate; turn off behind a TLS-terminating proxy
+	EnforceHosts  bool     `toml:"enforce_hosts"`  // Refuse requests for hostnames that are neither registered nor in allowed_hosts
+	AllowedHosts  []string `toml:"allowed_hosts"`  // Hostnames always served, for the default tenant
+	ACMEEmail     string   `toml:"acme_email"`     // Contact for the ACME account; ACME is off when empty
+	ACMEDirectory string   `toml:"acme_directory"` // ACME directory URL; Let's Encrypt when empty
+	ACMEHTTPPort  int      `toml:"acme_http_port"` // Port answering HTTP-01 challenges; 0 relies on TLS-ALPN-01 on the HTTPS port
+}
+
+// DocumentsConfig contains settings for document ingestion and retrieval
+// (/v1/documents)
+type DocumentsConfig struct {
+	Enabled      bool    `toml:"enabled"`
+	OCRModel     string  `toml:"ocr_model"`     // Vision model that transcribes images and scanned PDF pages; OCR is off when empty
+	MaxFileSize  int64   `toml:"max_file_size"` // Largest document accepted
+	MaxOCRPages  int     `toml:"max_ocr_pages"` // Images transcribed per document at most
+	ChunkSize    int     `toml:"chunk_size"`    // Characters per chunk
+	ChunkOverlap int     `toml:"chunk_overlap"` // Characters repeated at the start of the next chunk
+	TopK         int     `toml:"top_k"`         // Chunks retrieved per request
+	MinScore     float64 `toml:"min_score"`     // Chunks less similar to the question than this are not retrieved
+}
+
+// GraphQLConfig contains limits for the /graphql endpoint
+type GraphQLConfig struct {
+	Introspection         bool   `toml:"introspection"`           // Serve schema introspection and the playground; turn off in production
+	MaxDepth              int    `toml:"max_depth"`               // Deepest field nesting of an operation (0 disables)
+	MaxComplexity         int    `toml:"max_complexity"`          // Highest complexity of an operation, one per field selected (0 disables)
+	RateLimitPerMinute    int    `toml:"rate_limit_per_minute"`   // Operations per minute per signed-in user, or per client address without a valid session (0 disables)
+	PersistedQueriesFile  string `toml:"persisted_queries_file"`  // JSON object of SHA-256 hashes to the queries clients may send by hash
+	PersistedQueriesOnly  bool   `toml:"persisted_queries_only"`  // Reject queries that aren't in persisted_queries_file
+	PersistedQueriesCache int    `toml:"persisted_queries_cache"` // Automatic persisted queries remembered per instance
+}
+
+// IdempotencyConfig contains settings for Idempotency-Key on chat completions
+// and responses
+type IdempotencyConfig struct {
+	Enabled          bool          `toml:"enabled"`
+	TTL              time.Duration `toml:"ttl"`                // How long a response is replayed for its key
+	LockTimeout      time.Duration `toml:"lock_timeout"`       // A key whose request hasn't finished after this can be retried
+	MaxResponseBytes int           `toml:"max_response_bytes"` // Larger responses aren't stored, and their key is released
+	CleanupInterval  time.Duration `toml:"cleanup_interval"`   // How often expired keys are deleted
+}
+
+// PricingConfig contains model pricing settings
+type PricingConfig struct {
+	RefreshInterval time.Duration `toml:"refresh_interval"` // How often provider prices are refreshed (0 disables)
+}
+
+// RetentionConfig contains data retention settings. Table settings are defaults
+// that can be overridden per tenant through GraphQL.
+type RetentionConfig struct {
+	Enabled         bool                            `toml:"enabled"`
+	Interval        time.Duration                   `toml:"interval"`          // How often the retention janitor runs
+	BatchSize       int                             `toml:"batch_size"`        // Rows archived/deleted per statement
+	SoftDeleteGrace time.Duration                   `toml:"soft_delete_grace"` // How long soft-deleted rows are kept before purging
+	Tables          map[string]RetentionTableConfig `toml:"tables"`
+	Archive         ObjectStoreConfig               `toml:"archive"` // Where rows are exported before deletion
+}
+
+// RetentionTableConfig contains the retention defaults for one table
+type RetentionTableConfig struct {
+	RetentionDays int  `toml:"retention_days"` // 0 keeps rows forever
+	Archive       bool `toml:"archive"`        // Export rows to the archive store before deleting them
+}
+
+// ReplayConfig contains settings for capturing requests so they can be replayed
+type ReplayConfig struct {
+	CaptureContent  bool `toml:"capture_content"`   // Store the full request and response text with each usage record
+	MaxCaptureBytes int  `toml:"max_capture_bytes"` // Larger requests are recorded without their content
+}
+
+// UsageExportConfig contains settings for the scheduled export of usage data
+// to object storage, partitioned by time for loading into a data warehouse
+type UsageExportConfig struct {
+	Enabled            bool              `toml:"enabled"`
+	Interval           time.Duration     `toml:"interval"`             // How often the exporter looks for completed partitions
+	Format             string            `toml:"format"`               // "parquet" or "csv"
+	Partition          string            `toml:"partition"`            // "daily" or "hourly"
+	Delay              time.Duration     `toml:"delay"`                // Wait after a partition ends before exporting it, for late writes
+	IncludeRequestLogs bool              `toml:"include_request_logs"` // Also export request logs (errors and metadata)
+	Destination        ObjectStoreConfig `toml:"destination"`
+}
+
+// AuditExportConfig contains settings for on-demand audit log exports
+type AuditExportConfig struct {
+	DownloadTTL time.Duration     `toml:"download_ttl"` // How long a finished export can be downloaded
+	Destination ObjectStoreConfig `toml:"destination"`  // Optional; without it files are kept in the database for download
+}
+
+// EmailConfig contains the SMTP settings for outgoing email and the schedule
+// of usage digests
+type EmailConfig struct {
+	Enabled         bool          `toml:"enabled"`
+	SMTPHost        string        `toml:"smtp_host"`
+	SMTPPort        int           `toml:"smtp_port"`
+	Username        string        `toml:"username"`
+	Password        string        `toml:"password"`
+	From            string        `toml:"from"`              // e.g. "ModelGate <modelgate@example.com>"
+	TLS             string        `toml:"tls"`               // "starttls", "tls" (implicit, usually port 465) or "none"
+	DigestHour      int           `toml:"digest_hour"`       // Hour of the day (UTC) digests are sent
+	WeeklyDigestDay string        `toml:"weekly_digest_day"` // Day weekly digests are sent, e.g. "monday"
+	CheckInterval   time.Duration `toml:"check_interval"`    // How often due digests are looked for
+	DashboardURL    string        `toml:"dashboard_url"`     // Linked from digests, if set
+}
+
+// ObjectStoreConfig configures an object storage destination
+type ObjectStoreConfig struct {
+	Type            string `toml:"type"`   // "s3", "gcs", "azure" or "local"
+	Bucket          string `toml:"bucket"` // For Azure, the container
+	Prefix          string `toml:"prefix"`
+	Region          string `toml:"region"`
+	Endpoint        string `toml:"endpoint"`          // Custom S3-compatible endpoint (e.g. MinIO) or Azure blob endpoint
+	AccessKeyID     string `toml:"access_key_id"`     // For GCS, an HMAC key; for Azure, the storage account name
+	SecretAccessKey string `toml:"secret_access_key"` // For GCS, an HMAC secret; for Azure, the account key
+	LocalDir        string `toml:"local_dir"`         // Directory for the "local" type
 }
 
 // EmbedderConfig contains embedder settings for semantic search
@@ -34,6 +344,14 @@ type EmbedderConfig struct {
 	Model   string `toml:"model"`    // Model name (e.g., "text-embedding-3-small", "nomic-embed-text")
 }
 
+// EmbeddingRoutingConfig routes /v1/embeddings requests between providers
+type EmbeddingRoutingConfig struct {
+	// Models tried in order when a model's provider is down, throttled or out
+	// of quota. Only list models whose vectors are interchangeable with the
+	// model's, such as the same model at another provider.
+	Fallbacks map[string][]string `toml:"fallbacks"`
+}
+
 // ServerConfig contains server settings
 type ServerConfig struct {
 	HTTPPort       int           `toml:"http_port"`    // Unified API port (OpenAI + GraphQL + MCP)
@@ -50,6 +368,35 @@ type ServerConfig struct {
 	MaxQueuedRequests  int     `toml:"max_queued_requests"`  // Max requests waiting in queue
 	ScaleUpThreshold   float64 `toml:"scale_up_threshold"`   // Queue utilization % to scale up
 	ScaleDownThreshold float64 `toml:"scale_down_threshold"` // Queue utilization % to scale down
+
+	// Default in-flight limits (0 = unlimited); role concurrency policies override these
+	MaxConcurrentPerKey     int  `toml:"max_concurrent_per_key"`     // Per API key
+	MaxConcurrentPerRole    int  `toml:"max_concurrent_per_role"`    // Per role
+	QueueOnConcurrencyLimit bool `toml:"queue_on_concurrency_limit"` // Wait for a slot instead of returning 429
+
+	// Fair queuing: within a priority band, queued requests are shared out
+	// across roles (or API keys) by their concurrency policy weight
+	FairnessKey       string        `toml:"fairness_key"`       // "role" (default) or "api_key"
+	StarvationTimeout time.Duration `toml:"starvation_timeout"` // Serve requests queued this long next regardless of priority (default 10s)
+
+	// Durable queue: persist accepted non-streaming requests in Postgres so a
+	// restart picks them up, and dedupe retries by Idempotency-Key
+	DurableQueue          bool          `toml:"durable_queue"`
+	DurableQueueRetention time.Duration `toml:"durable_queue_retention"` // How long finished requests are kept for retries (default 24h)
+
+	// Graceful shutdown: on SIGTERM or POST /admin/drain, new requests get 503
+	// and in-flight requests, streams and queued work get this long to finish
+	DrainTimeout time.Duration `toml:"drain_timeout"` // Default 30s
+
+	// TLS and client certificate (mTLS) verification; plain HTTP when TLSCertFile is empty
+	TLSCertFile  string `toml:"tls_cert_file"`
+	TLSKeyFile   string `toml:"tls_key_file"`
+	ClientCAFile string `toml:"client_ca_file"` // CA bundle used to verify client certificates
+	ClientAuth   string `toml:"client_auth"`    // "none", "optional" (verify if presented), "require"
+
+	// Proxies whose X-Forwarded-For header is trusted when resolving the client IP
+	// for API key and role IP allowlists. Other peers are identified by their address.
+	TrustedProxies []string `toml:"trusted_proxies"`
 }
 
 // TelemetryConfig contains telemetry settings
@@ -79,6 +426,15 @@ type DatabaseConfig struct {
 	MaxConns   int           `toml:"max_conns"`
 	MaxIdle    int           `toml:"max_idle"`
 	ConnMaxAge time.Duration `toml:"conn_max_age"`
+
+	Replicas ReplicaConfig `toml:"replicas"`
+}
+
+// ReplicaConfig routes read-only analytics queries to streaming replicas
+type ReplicaConfig struct {
+	DSNs          []string      `toml:"dsns"`           // Replica connection strings; empty runs every query on the primary
+	MaxLag        time.Duration `toml:"max_lag"`        // Replicas further behind the primary than this are skipped
+	CheckInterval time.Duration `toml:"check_interval"` // How often replica health and lag are checked
 }
 
 // GetDSN returns the DSN for the database
@@ -149,15 +505,18 @@ type OllamaConfig struct {
 
 // ModelConfig contains model metadata
 type ModelConfig struct {
-	Name              string  `toml:"name"`
-	Provider          string  `toml:"provider"`
-	SupportsTools     bool    `toml:"supports_tools"`
-	SupportsReasoning bool    `toml:"supports_reasoning"`
-	ContextLimit      uint32  `toml:"context_limit"`
-	OutputLimit       uint32  `toml:"output_limit"`
-	InputCostPer1M    float64 `toml:"input_cost_per_1m"`
-	OutputCostPer1M   float64 `toml:"output_cost_per_1m"`
-	Enabled           bool    `toml:"enabled"`
+	Name              string `toml:"name"`
+	Provider          string `toml:"provider"`
+	SupportsTools     bool   `toml:"supports_tools"`
+	SupportsReasoning bool   `toml:"supports_reasoning"`
+	// SupportsVision says whether the model accepts image inputs; unset
+	// leaves it to the provider's model list and the bundled defaults
+	SupportsVision  *bool   `toml:"supports_vision"`
+	ContextLimit    uint32  `toml:"context_limit"`
+	OutputLimit     uint32  `toml:"output_limit"`
+	InputCostPer1M  float64 `toml:"input_cost_per_1m"`
+	OutputCostPer1M float64 `toml:"output_cost_per_1m"`
+	Enabled         bool    `toml:"enabled"`
 }
 
 // PolicyConfig contains default policy settings
@@ -203,6 +562,9 @@ func Default() *Config {
 			ReadTimeout:    5 * time.Minute,  // Increased for long streaming requests
 			WriteTimeout:   10 * time.Minute, // Increased for long streaming responses
 			MaxRequestSize: 10 * 1024 * 1024, // 10MB
+
+			DurableQueueRetention: 24 * time.Hour,
+			DrainTimeout:          30 * time.Second,
 		},
 		Telemetry: TelemetryConfig{
 			Enabled:     true,
@@ -224,6 +586,10 @@ func Default() *Config {
 			MaxConns:   20,
 			MaxIdle:    5,
 			ConnMaxAge: 30 * time.Minute,
+			Replicas: ReplicaConfig{
+				MaxLag:        30 * time.Second,
+				CheckInterval: 10 * time.Second,
+			},
 		},
 		Providers: ProvidersConfig{
 			Ollama: OllamaConfig{
@@ -248,6 +614,158 @@ func Default() *Config {
 			DefaultTPM:          100000,
 			APIKeyHashAlgorithm: "sha256",
 		},
+		Files: FilesConfig{
+			StorageDir:      "data/files",
+			MaxFileSize:     8 * 1024 * 1024 * 1024, // 8GB
+			MaxPartSize:     64 * 1024 * 1024,       // 64MB
+			UploadTTL:       time.Hour,
+			CleanupInterval: 10 * time.Minute,
+			Backend:         "local",
+			MaxInlineSize:   20 * 1024 * 1024, // 20MB
+		},
+		Batches: BatchesConfig{
+			PollInterval: time.Minute,
+		},
+		FineTuning: FineTuningConfig{
+			PollInterval: time.Minute,
+		},
+		Responses: ResponsesConfig{
+			ValidationRetries: 2,
+		},
+		Pricing: PricingConfig{
+			RefreshInterval: 24 * time.Hour,
+		},
+		Export: UsageExportConfig{
+			Interval:  15 * time.Minute,
+			Format:    "parquet",
+			Partition: "daily",
+			Delay:     10 * time.Minute,
+		},
+		AuditExport: AuditExportConfig{
+			DownloadTTL: 24 * time.Hour,
+		},
+		Replay: ReplayConfig{
+			MaxCaptureBytes: 256 * 1024, // 256KB
+		},
+		Email: EmailConfig{
+			SMTPPort:        587,
+			TLS:             "starttls",
+			DigestHour:      8,
+			WeeklyDigestDay: "monday",
+			CheckInterval:   15 * time.Minute,
+		},
+		Forecast: ForecastConfig{
+			AlertsEnabled: true,
+			CheckInterval: time.Hour,
+		},
+		Registration: RegistrationConfig{
+			MaxPending:  100,
+			DefaultRole: "member",
+		},
+		StickyRouting: StickyRoutingConfig{
+			Enabled:          true,
+			TTL:              30 * time.Minute,
+			MaxConversations: 100000,
+		},
+		Idempotency: IdempotencyConfig{
+			Enabled:          true,
+			TTL:              24 * time.Hour,
+			LockTimeout:      15 * time.Minute,
+			MaxResponseBytes: 4 * 1024 * 1024, // 4MB
+			CleanupInterval:  time.Hour,
+		},
+		ToolApproval: ToolApprovalConfig{
+			Enabled: true,
+		},
+		Discovery: ModelDiscoveryConfig{
+			Enabled:     true,
+			Interval:    6 * time.Hour,
+			RemoveAfter: 7 * 24 * time.Hour,
+		},
+		DataAudit: DataPlaneAuditConfig{
+			BufferSize:      10000,
+			BatchSize:       500,
+			FlushInterval:   time.Second,
+			RetentionMonths: 3,
+		},
+		EventStream: EventStreamConfig{
+			Broker:       "kafka",
+			Format:       "json",
+			Events:       []string{"usage", "policy_violation", "audit"},
+			BatchSize:    100,
+			PollInterval: time.Second,
+			Retention:    24 * time.Hour,
+		},
+		Attestation: AttestationConfig{
+			RotateAfter: 90 * 24 * time.Hour,
+		},
+		HealthHistory: HealthHistoryConfig{
+			Enabled:       true,
+			Bucket:        5 * time.Minute,
+			Retention:     400 * 24 * time.Hour,
+			SLATarget:     99.9,
+			DegradedBelow: 0.9,
+		},
+		Passwords: PasswordConfig{
+			Algorithm:          "bcrypt",
+			BcryptCost:         10,
+			Argon2Memory:       64 * 1024,
+			Argon2Iterations:   3,
+			Argon2Parallelism:  2,
+			MinLength:          8,
+			LockoutThreshold:   5,
+			LockoutDuration:    time.Minute,
+			MaxLockoutDuration: time.Hour,
+		},
+		TwoFactor: TwoFactorConfig{
+			Issuer:      "ModelGate",
+			Skew:        1,
+			BackupCodes: 10,
+		},
+		Deprecation: ModelDeprecationConfig{
+			AlertBefore:   30 * 24 * time.Hour,
+			CheckInterval: time.Hour,
+		},
+		Domains: CustomDomainsConfig{
+			TerminateTLS: true,
+			EnforceHosts: true,
+			AllowedHosts: []string{"localhost", "127.0.0.1", "::1"},
+		},
+		Documents: DocumentsConfig{
+			MaxFileSize:  20 * 1024 * 1024, // 20MB
+			MaxOCRPages:  20,
+			ChunkSize:    1000,
+			ChunkOverlap: 150,
+			TopK:         5,
+			MinScore:     0.3,
+		},
+		GraphQL: GraphQLConfig{
+			Introspection:         true,
+			MaxDepth:              15,
+			MaxComplexity:         1000,
+			RateLimitPerMinute:    300,
+			PersistedQueriesCache: 1000,
+		},
+		Warmup: ConnectionWarmupConfig{
+			Enabled:      true,
+			Connections:  2,
+			PingInterval: 30 * time.Second,
+		},
+		Threads: ThreadsConfig{
+			MaxMessages:     200,
+			MaxBytes:        1024 * 1024, // 1MB
+			TTL:             30 * 24 * time.Hour,
+			CleanupInterval: time.Hour,
+		},
+		Retention: RetentionConfig{
+			Interval:        time.Hour,
+			BatchSize:       5000,
+			SoftDeleteGrace: 7 * 24 * time.Hour,
+			Tables: map[string]RetentionTableConfig{
+				"usage_records": {RetentionDays: 90},
+				"audit_logs":    {RetentionDays: 365},
+			},
+		},
 	}
 }
 
@@ -302,8 +820,19 @@ func (c *Config) substituteEnvVars() {
 	c.Database.Host = expandEnv(c.Database.Host)
 	c.Database.User = expandEnv(c.Database.User)
 	c.Database.Password = expandEnv(c.Database.Password)
+	for i, dsn := range c.Database.Replicas.DSNs {
+		c.Database.Replicas.DSNs[i] = expandEnv(dsn)
+	}
 	c.Security.JWTSecret = expandEnv(c.Security.JWTSecret)
 	c.Security.AdminAPIKey = expandEnv(c.Security.AdminAPIKey)
+	c.Export.Destination.AccessKeyID = expandEnv(c.Export.Destination.AccessKeyID)
+	c.Export.Destination.SecretAccessKey = expandEnv(c.Export.Destination.SecretAccessKey)
+	c.AuditExport.Destination.AccessKeyID = expandEnv(c.AuditExport.Destination.AccessKeyID)
+	c.AuditExport.Destination.SecretAccessKey = expandEnv(c.AuditExport.Destination.SecretAccessKey)
+	c.Files.ObjectStore.AccessKeyID = expandEnv(c.Files.ObjectStore.AccessKeyID)
+	c.Files.ObjectStore.SecretAccessKey = expandEnv(c.Files.ObjectStore.SecretAccessKey)
+	c.Email.Username = expandEnv(c.Email.Username)
+	c.Email.Password = expandEnv(c.Email.Password)
 
 	// Direct environment variable overrides for Docker deployment
 	// Database configuration
@@ -330,6 +859,9 @@ func (c *Config) substituteEnvVars() {
 	if v := os.Getenv("MODELGATE_DB_SSL_MODE"); v != "" {
 		c.Database.SSLMode = v
 	}
+	if v := os.Getenv("MODELGATE_DB_REPLICA_DSNS"); v != "" {
+		c.Database.Replicas.DSNs = strings.Split(v, ",")
+	}
 
 	// Server configuration
 	if v := os.Getenv("MODELGATE_HTTP_PORT"); v != "" {
@@ -420,6 +952,7 @@ func (m *ModelConfig) ToModelInfo(id string) domain.ModelInfo {
 		Provider:          provider,
 		SupportsTools:     m.SupportsTools,
 		SupportsReasoning: m.SupportsReasoning,
+		SupportsVision:    m.SupportsVision != nil && *m.SupportsVision,
 		ContextLimit:      m.ContextLimit,
 		OutputLimit:       m.OutputLimit,
 		InputCostPer1M:    m.InputCostPer1M,
@@ -0,0 +1,433 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+// Holder owns the live configuration and swaps it atomically on reload.
+// Readers that keep the *Config they got from Get see a consistent snapshot
+// for the rest of their request, so in-flight streams are never affected.
+type Holder struct {
+	path    string
+	current atomic.Pointer[Config]
+
+	mu    sync.Mutex // Serializes reloads
+	hooks []reloadHook
+}
+
+type reloadHook struct {
+	name string
+	fn   func(old, new *Config)
+}
+
+// ReloadResult describes what a reload changed
+type ReloadResult struct {
+	Changed         []string // Top-level sections that differ from the previous config
+	RestartRequired []string // Settings that changed on disk but only apply after a restart
+}
+
+// NewHolder creates a holder for the config loaded from path
+func NewHolder(path string, cfg *Config) *Holder {
+	h := &Holder{path: path}
+	h.current.Store(cfg)
+	return h
+}
+
+// Get returns the current configuration
+func (h *Holder) Get() *Config {
+	return h.current.Load()
+}
+
+// OnReload registers a function called with the old and new config after each
+// successful reload. Hooks run in registration order.
+func (h *Holder) OnReload(name string, fn func(old, new *Config)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.hooks = append(h.hooks, reloadHook{name: name, fn: fn})
+}
+
+// Reload reads the config file again, validates it and swaps it in. On any
+// error the current config stays in place. Settings that can't change at
+// runtime (database, listener, queue capacity) keep their current values and
+// are reported in RestartRequired.
+func (h *Holder) Reload() (*ReloadResult, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// Load falls back to defaults for a missing file, which is never what a reload wants
+	if _, err := os.Stat(h.path); err != nil {
+		return nil, fmt.Errorf("reading config: %w", err)
+	}
+	next, err := Load(h.path)
+	if err != nil {
+		return nil, err
+	}
+	if err := next.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	old := h.current.Load()
+	result := &ReloadResult{RestartRequired: pinStaticSettings(old, next)}
+	result.Changed = changedSections(old, next)
+	if len(result.Changed) == 0 {
+		return result, nil
+	}
+
+	h.current.Store(next)
+	for _, hook := range h.hooks {
+		slog.Debug("Applying reloaded config", "hook", hook.name)
+		hook.fn(old, next)
+	}
+
+	slog.Info("Configuration reloaded", "changed", result.Changed, "restart_required", result.RestartRequired)
+	return result, nil
+}
+
+// pinStaticSettings copies settings that only apply at startup from old to
+// next and returns the names of the ones that differed
+func pinStaticSettings(old, next *Config) []string {
+	var pinned []string
+	pin := func(name string, cur, want any, restore func()) {
+		if !reflect.DeepEqual(cur, want) {
+			pinned = append(pinned, name)
+			restore()
+		}
+	}
+
+	pin("database", old.Database, next.Database, func() { next.Database = old.Database })
+	pin("server.http_port", old.Server.HTTPPort, next.Server.HTTPPort, func() { next.Server.HTTPPort = old.Server.HTTPPort })
+	pin("server.bind_address", old.Server.BindAddress, next.Server.BindAddress, func() { next.Server.BindAddress = old.Server.BindAddress })
+	pin("server.read_timeout", old.Server.ReadTimeout, next.Server.ReadTimeout, func() { next.Server.ReadTimeout = old.Server.ReadTimeout })
+	pin("server.write_timeout", old.Server.WriteTimeout, next.Server.WriteTimeout, func() { next.Server.WriteTimeout = old.Server.WriteTimeout })
+	pin("server.tls", tlsSettings(old.Server), tlsSettings(next.Server), func() {
+		next.Server.TLSCertFile, next.Server.TLSKeyFile = old.Server.TLSCertFile, old.Server.TLSKeyFile
+		next.Server.ClientCAFile, next.Server.ClientAuth = old.Server.ClientCAFile, old.Server.ClientAuth
+	})
+	pin("server.durable_queue", [2]any{old.Server.DurableQueue, old.Server.DurableQueueRetention}, [2]any{next.Server.DurableQueue, next.Server.DurableQueueRetention}, func() {
+		next.Server.DurableQueue, next.Server.DurableQueueRetention = old.Server.DurableQueue, old.Server.DurableQueueRetention
+	})
+	pin("server.max_queued_requests", old.Server.MaxQueuedRequests, next.Server.MaxQueuedRequests, func() { next.Server.MaxQueuedRequests = old.Server.MaxQueuedRequests })
+	pin("telemetry.prometheus_port", old.Telemetry.PrometheusPort, next.Telemetry.PrometheusPort, func() { next.Telemetry.PrometheusPort = old.Telemetry.PrometheusPort })
+	pin("pricing.refresh_interval", old.Pricing.RefreshInterval, next.Pricing.RefreshInterval, func() { next.Pricing.RefreshInterval = old.Pricing.RefreshInterval })
+	pin("usage_export", old.Export, next.Export, func() { next.Export = old.Export })
+	pin("audit_export", old.AuditExport, next.AuditExport, func() { next.AuditExport = old.AuditExport })
+	pin("threads", old.Threads, next.Threads, func() { next.Threads = old.Threads })
+	pin("custom_domains.tls", domainTLSSettings(old.Domains), domainTLSSettings(next.Domains), func() {
+		allowed, enforce := next.Domains.AllowedHosts, next.Domains.EnforceHosts
+		next.Domains = old.Domains
+		next.Domains.AllowedHosts, next.Domains.EnforceHosts = allowed, enforce
+	})
+	pin("passwords", old.Passwords, next.Passwords, func() { next.Passwords = old.Passwords })
+	pin("two_factor", old.TwoFactor, next.TwoFactor, func() { next.TwoFactor = old.TwoFactor })
+	pin("health_history", old.HealthHistory, next.HealthHistory, func() { next.HealthHistory = old.HealthHistory })
+	pin("documents", old.Documents, next.Documents, func() { next.Documents = old.Documents })
+	pin("graphql.persisted_queries", graphQLPersistedSettings(old.GraphQL), graphQLPersistedSettings(next.GraphQL), func() {
+		next.GraphQL.PersistedQueriesFile, next.GraphQL.PersistedQueriesCache = old.GraphQL.PersistedQueriesFile, old.GraphQL.PersistedQueriesCache
+	})
+	pin("files.storage_dir", old.Files.StorageDir, next.Files.StorageDir, func() { next.Files.StorageDir = old.Files.StorageDir })
+
+	return pinned
+}
+
+func tlsSettings(s ServerConfig) [4]string {
+	return [4]string{s.TLSCertFile, s.TLSKeyFile, s.ClientCAFile, s.ClientAuth}
+}
+
+// domainTLSSettings are the custom domain settings applied when the listener starts
+func domainTLSSettings(d CustomDomainsConfig) [5]any {
+	return [5]any{d.Enabled, d.TerminateTLS, d.ACMEEmail, d.ACMEDirectory, d.ACMEHTTPPort}
+}
+
+// graphQLPersistedSettings are the persisted query settings loaded when the
+// GraphQL handler is built
+func graphQLPersistedSettings(g GraphQLConfig) [2]any {
+	return [2]any{g.PersistedQueriesFile, g.PersistedQueriesCache}
+}
+
+// changedSections returns the top-level sections (by TOML name) that differ
+func changedSections(old, next *Config) []string {
+	var changed []string
+	ov, nv := reflect.ValueOf(old).Elem(), reflect.ValueOf(next).Elem()
+	t := ov.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if !reflect.DeepEqual(ov.Field(i).Interface(), nv.Field(i).Interface()) {
+			changed = append(changed, t.Field(i).Tag.Get("toml"))
+		}
+	}
+	return changed
+}
+
+// Validate checks the configuration for values the gateway can't run with
+func (c *Config) Validate() error {
+	var errs []string
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Sprintf(format, args...))
+	}
+
+	s := c.Server
+	if s.HTTPPort < 1 || s.HTTPPort > 65535 {
+		fail("server.http_port %d is out of range", s.HTTPPort)
+	}
+	if s.MinWorkers < 0 || s.MaxWorkers < 0 || s.MaxQueuedRequests < 0 {
+		fail("server worker and queue sizes must not be negative")
+	}
+	if s.MinWorkers > 0 && s.MaxWorkers > 0 && s.MinWorkers > s.MaxWorkers {
+		fail("server.min_workers (%d) is greater than server.max_workers (%d)", s.MinWorkers, s.MaxWorkers)
+	}
+	if s.ScaleUpThreshold < 0 || s.ScaleUpThreshold > 1 || s.ScaleDownThreshold < 0 || s.ScaleDownThreshold > 1 {
+		fail("server scale thresholds must be between 0 and 1")
+	}
+	if s.ScaleUpThreshold > 0 && s.ScaleDownThreshold >= s.ScaleUpThreshold {
+		fail("server.scale_down_threshold must be below server.scale_up_threshold")
+	}
+	if s.MaxConcurrentPerKey < 0 || s.MaxConcurrentPerRole < 0 {
+		fail("server concurrency limits must not be negative")
+	}
+	switch s.FairnessKey {
+	case "", "role", "api_key":
+	default:
+		fail("server.fairness_key %q is not one of role, api_key", s.FairnessKey)
+	}
+	if s.StarvationTimeout < 0 {
+		fail("server.starvation_timeout must not be negative")
+	}
+	if s.DrainTimeout < 0 {
+		fail("server.drain_timeout must not be negative")
+	}
+
+	if (s.TLSCertFile == "") != (s.TLSKeyFile == "") {
+		fail("server.tls_cert_file and server.tls_key_file must be set together")
+	}
+	switch s.ClientAuth {
+	case "", "none":
+	case "optional", "require":
+		if s.TLSCertFile == "" || s.ClientCAFile == "" {
+			fail("server.client_auth %q requires server.tls_cert_file and server.client_ca_file", s.ClientAuth)
+		}
+	default:
+		fail("server.client_auth %q is not one of none, optional, require", s.ClientAuth)
+	}
+	for _, cidr := range s.TrustedProxies {
+		if _, err := domain.ParseCIDR(cidr); err != nil {
+			fail("server.trusted_proxies: %v", err)
+		}
+	}
+
+	if r := c.Database.Replicas; len(r.DSNs) > 0 && (r.MaxLag <= 0 || r.CheckInterval <= 0) {
+		fail("database.replicas.max_lag and check_interval must be positive")
+	}
+
+	switch c.Embedder.Type {
+	case "", "openai", "ollama", "local":
+	default:
+		fail("embedder.type %q is not one of openai, ollama, local", c.Embedder.Type)
+	}
+
+	switch strings.ToLower(c.Telemetry.LogLevel) {
+	case "", "debug", "info", "warn", "error":
+	default:
+		fail("telemetry.log_level %q is not one of debug, info, warn, error", c.Telemetry.LogLevel)
+	}
+
+	if c.Export.Enabled {
+		if c.Export.Format != "parquet" && c.Export.Format != "csv" {
+			fail("usage_export.format %q is not one of parquet, csv", c.Export.Format)
+		}
+		if c.Export.Partition != "daily" && c.Export.Partition != "hourly" {
+			fail("usage_export.partition %q is not one of daily, hourly", c.Export.Partition)
+		}
+		if c.Export.Destination.Type == "" {
+			fail("usage_export.destination.type is required when usage export is enabled")
+		}
+	}
+
+	switch c.Files.Backend {
+	case "", "local":
+	case "object_store":
+		if c.Files.ObjectStore.Type == "" {
+			fail("files.object_store.type is required with the object_store backend")
+		}
+	default:
+		fail("files.backend %q is not one of local, object_store", c.Files.Backend)
+	}
+	if c.Files.Retention < 0 {
+		fail("files.retention must not be negative")
+	}
+
+	if c.AuditExport.DownloadTTL < 0 {
+		fail("audit_export.download_ttl must not be negative")
+	}
+
+	if c.Replay.MaxCaptureBytes < 0 {
+		fail("replay.max_capture_bytes must not be negative")
+	}
+
+	if c.Threads.MaxMessages < 0 || c.Threads.MaxBytes < 0 || c.Threads.TTL < 0 {
+		fail("threads limits must not be negative")
+	}
+
+	if c.Responses.ValidationRetries < 0 {
+		fail("responses.validation_retries must not be negative")
+	}
+	if m := c.Responses.RepairModel; m != "" {
+		if _, ok := c.GetProviderForModel(m); !ok {
+			fail("responses.repair_model %q has no known provider", m)
+		}
+	}
+
+	if c.Forecast.AlertsEnabled && c.Forecast.CheckInterval < time.Minute {
+		fail("forecast.check_interval must be at least 1m")
+	}
+	switch c.Registration.DefaultRole {
+	case "admin", "member", "viewer":
+	default:
+		fail("registration.default_role must be admin, member or viewer")
+	}
+	if c.Registration.MaxPending < 0 {
+		fail("registration.max_pending must not be negative")
+	}
+	if c.StickyRouting.Enabled && c.StickyRouting.TTL <= 0 {
+		fail("sticky_routing.ttl must be positive")
+	}
+	if c.StickyRouting.MaxConversations < 0 {
+		fail("sticky_routing.max_conversations must not be negative")
+	}
+	if c.Idempotency.Enabled && (c.Idempotency.TTL <= 0 || c.Idempotency.LockTimeout <= 0) {
+		fail("idempotency.ttl and idempotency.lock_timeout must be positive")
+	}
+	if c.Idempotency.MaxResponseBytes < 0 {
+		fail("idempotency.max_response_bytes must not be negative")
+	}
+	if c.Discovery.Enabled && c.Discovery.Interval <= 0 {
+		fail("model_discovery.interval must be positive")
+	}
+	if c.Discovery.RemoveAfter < 0 {
+		fail("model_discovery.remove_after must not be negative")
+	}
+	if c.DataAudit.BufferSize <= 0 || c.DataAudit.BatchSize <= 0 || c.DataAudit.FlushInterval <= 0 {
+		fail("data_plane_audit.buffer_size, batch_size and flush_interval must be positive")
+	}
+	if c.DataAudit.RetentionMonths < 0 {
+		fail("data_plane_audit.retention_months must not be negative")
+	}
+	if es := c.EventStream; es.Enabled {
+		switch es.Broker {
+		case "kafka":
+			if len(es.Brokers) == 0 {
+				fail("event_stream.brokers is required for kafka")
+			}
+		case "nats":
+			if es.NATSURL == "" {
+				fail("event_stream.nats_url is required for nats")
+			}
+		default:
+			fail("event_stream.broker must be kafka or nats")
+		}
+		if es.Format != "json" && es.Format != "protobuf" {
+			fail("event_stream.format must be json or protobuf")
+		}
+		for _, e := range es.Events {
+			if !domain.StreamEventType(e).Valid() {
+				fail("event_stream.events has unknown event type %q", e)
+			}
+		}
+		for e := range es.Topics {
+			if !domain.StreamEventType(e).Valid() {
+				fail("event_stream.topics has unknown event type %q", e)
+			}
+		}
+		if es.BatchSize <= 0 || es.PollInterval <= 0 {
+			fail("event_stream.batch_size and poll_interval must be positive")
+		}
+		if es.Retention < 0 {
+			fail("event_stream.retention must not be negative")
+		}
+	}
+	if c.Attestation.RotateAfter < 0 || c.Attestation.Retention < 0 {
+		fail("attestation.rotate_after and retention must not be negative")
+	}
+	if c.Warmup.Enabled && c.Warmup.Connections <= 0 {
+		fail("connection_warmup.connections must be positive")
+	}
+	if c.Deprecation.AlertBefore < 0 || c.Deprecation.CheckInterval <= 0 {
+		fail("model_deprecation.alert_before must not be negative and check_interval must be positive")
+	}
+	if c.Warmup.PingInterval < 0 {
+		fail("connection_warmup.ping_interval must not be negative")
+	}
+	if c.Domains.ACMEHTTPPort < 0 || c.Domains.ACMEHTTPPort > 65535 {
+		fail("custom_domains.acme_http_port must be a port number")
+	}
+	if c.Domains.ACMEHTTPPort > 0 && c.Domains.ACMEEmail == "" {
+		fail("custom_domains.acme_http_port requires acme_email")
+	}
+	if c.Documents.Enabled {
+		if c.Documents.MaxFileSize <= 0 || c.Documents.MaxOCRPages < 0 {
+			fail("documents.max_file_size must be positive and max_ocr_pages must not be negative")
+		}
+		if c.Documents.ChunkSize <= 0 || c.Documents.ChunkOverlap < 0 || c.Documents.ChunkOverlap >= c.Documents.ChunkSize {
+			fail("documents.chunk_size must be positive and chunk_overlap between 0 and chunk_size")
+		}
+		if c.Documents.TopK <= 0 || c.Documents.MinScore < 0 || c.Documents.MinScore > 1 {
+			fail("documents.top_k must be positive and min_score between 0 and 1")
+		}
+	}
+	if c.GraphQL.MaxDepth < 0 || c.GraphQL.MaxComplexity < 0 || c.GraphQL.RateLimitPerMinute < 0 || c.GraphQL.PersistedQueriesCache < 0 {
+		fail("graphql limits must not be negative")
+	}
+	if hh := c.HealthHistory; hh.Enabled && (hh.Bucket < time.Minute || hh.Bucket > 24*time.Hour || (24*time.Hour)%hh.Bucket != 0) {
+		fail("health_history.bucket must divide a day and be between 1m and 24h")
+	}
+	if hh := c.HealthHistory; hh.Retention < 0 || hh.SLATarget < 0 || hh.SLATarget > 100 || hh.DegradedBelow < 0 || hh.DegradedBelow > 1 {
+		fail("health_history: retention must not be negative, sla_target must be a percentage and degraded_below a fraction")
+	}
+	switch pw := c.Passwords; {
+	case pw.Algorithm != "bcrypt" && pw.Algorithm != "argon2id":
+		fail("passwords.algorithm must be bcrypt or argon2id")
+	case pw.BcryptCost < 4 || pw.BcryptCost > 31:
+		fail("passwords.bcrypt_cost must be between 4 and 31")
+	case pw.Algorithm == "argon2id" && (pw.Argon2Memory < 8*uint32(pw.Argon2Parallelism) || pw.Argon2Iterations < 1 || pw.Argon2Parallelism < 1):
+		fail("passwords: argon2_iterations and argon2_parallelism must be positive and argon2_memory at least 8 KiB per lane")
+	case pw.MinLength < 1 || pw.MinCharClasses < 0 || pw.MinCharClasses > 4:
+		fail("passwords.min_length must be positive and min_char_classes between 0 and 4")
+	case pw.MaxAge < 0 || pw.History < 0 || pw.LockoutThreshold < 0:
+		fail("passwords: max_age, history and lockout_threshold must not be negative")
+	case pw.LockoutThreshold > 0 && (pw.LockoutDuration <= 0 || pw.MaxLockoutDuration < pw.LockoutDuration):
+		fail("passwords.lockout_duration must be positive and max_lockout_duration at least as long")
+	}
+	if tf := c.TwoFactor; tf.Issuer == "" || strings.Contains(tf.Issuer, ":") || tf.Skew < 0 || tf.Skew > 10 || tf.BackupCodes < 1 || tf.BackupCodes > 50 {
+		fail("two_factor: issuer must be set without a colon, skew between 0 and 10 and backup_codes between 1 and 50")
+	}
+	for model, fallbacks := range c.Embeddings.Fallbacks {
+		for _, fallback := range fallbacks {
+			if _, ok := c.GetProviderForModel(fallback); !ok {
+				fail("embedding_routing.fallbacks.%s: %q must name its provider, as provider/model", model, fallback)
+			} else if fallback == model {
+				fail("embedding_routing.fallbacks.%s lists the model itself", model)
+			}
+		}
+	}
+	if c.GraphQL.PersistedQueriesOnly && c.GraphQL.PersistedQueriesFile == "" {
+		fail("graphql.persisted_queries_only requires persisted_queries_file")
+	}
+
+	for id, m := range c.Models {
+		if _, ok := domain.ParseProvider(m.Provider); !ok {
+			fail("models.%s has unknown provider %q", id, m.Provider)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReloadSwapsValidConfigAndPinsStaticSettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	write := func(body string) {
+		if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("[server]\nhttp_port = 8080\nmax_workers = 50\n")
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewHolder(path, cfg)
+
+	var hookCalls int
+	h.OnReload("test", func(old, next *Config) {
+		hookCalls++
+		if old.Server.MaxWorkers != 50 || next.Server.MaxWorkers != 100 {
+			t.Errorf("hook got max_workers %d -> %d, want 50 -> 100", old.Server.MaxWorkers, next.Server.MaxWorkers)
+		}
+	})
+
+	// An invalid file is rejected and the current config stays in place
+	write("[server]\nhttp_port = 8080\nmin_workers = 10\nmax_workers = 5\n")
+	if _, err := h.Reload(); err == nil {
+		t.Fatal("expected min_workers > max_workers to be rejected")
+	}
+	if h.Get() != cfg || hookCalls != 0 {
+		t.Fatal("expected a failed reload to keep the current config")
+	}
+
+	write("[server]\nhttp_port = 9090\nmax_workers = 100\n")
+	result, err := h.Reload()
+	if err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if hookCalls != 1 || h.Get().Server.MaxWorkers != 100 {
+		t.Errorf("expected the new config to be swapped in, got max_workers %d", h.Get().Server.MaxWorkers)
+	}
+	if h.Get().Server.HTTPPort != 8080 || len(result.RestartRequired) != 1 || result.RestartRequired[0] != "server.http_port" {
+		t.Errorf("expected http_port to stay at 8080 and need a restart, got %d (%v)", h.Get().Server.HTTPPort, result.RestartRequired)
+	}
+	if len(result.Changed) != 1 || result.Changed[0] != "server" {
+		t.Errorf("changed = %v, want [server]", result.Changed)
+	}
+}
@@ -0,0 +1,132 @@
+// Package configsync applies a declarative description of roles, role
+// policies, groups and provider settings to the database, so gateway
+// configuration can be kept in version control and applied idempotently.
+package configsync
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+
+	"modelgate/internal/domain"
+)
+
+// Document is a declarative gateway configuration. Entries are matched to
+// existing ones by role name, group name and provider. The fields of an entry
+// are applied over the existing values, so an entry only needs the fields it
+// manages; a role's policy is left alone unless the entry has one.
+type Document struct {
+	Roles     []json.RawMessage `json:"roles,omitempty"`
+	Groups    []json.RawMessage `json:"groups,omitempty"`
+	Providers []json.RawMessage `json:"providers,omitempty"`
+}
+
+// roleSpec is a role entry; field names follow the JSON form of domain.Role
+// and domain.RolePolicy
+type roleSpec struct {
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	Permissions []string           `json:"permissions"`
+	IsDefault   bool               `json:"is_default"`
+	Policy      *domain.RolePolicy `json:"policy,omitempty"`
+}
+
+// groupSpec is a group entry, with its roles given by name
+type groupSpec struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Roles       []string `json:"roles"`
+}
+
+// providerSpec is a provider entry. Credentials are not part of it; provider
+// API keys are managed with `modelgate provider set-key` or the admin UI.
+type providerSpec struct {
+	Provider           domain.Provider           `json:"provider"`
+	Enabled            bool                      `json:"enabled"`
+	BaseURL            string                    `json:"base_url,omitempty"`
+	Region             string                    `json:"region,omitempty"`
+	RegionPrefix       string                    `json:"region_prefix,omitempty"`
+	ModelsURL          string                    `json:"models_url,omitempty"`
+	ResourceName       string                    `json:"resource_name,omitempty"`
+	APIVersion         string                    `json:"api_version,omitempty"`
+	ConnectionSettings domain.ConnectionSettings `json:"connection_settings"`
+	Throttles          []domain.ProviderThrottle `json:"throttles,omitempty"`
+	Regions            []domain.ProviderRegion   `json:"regions,omitempty"`
+	KeyStrategy        domain.KeyStrategy        `json:"key_strategy,omitempty"`
+
+	HuggingFaceEndpoints []domain.HuggingFaceEndpoint `json:"huggingface_endpoints,omitempty"`
+}
+
+// Parse reads a document in YAML or JSON (JSON being a subset of YAML)
+func Parse(data []byte) (*Document, error) {
+	var raw any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parse document: %w", err)
+	}
+	if raw == nil {
+		return &Document{}, nil
+	}
+	// Round-trip through JSON so entries are decoded with the JSON field
+	// names the rest of the gateway uses
+	jsonData, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parse document: %w", err)
+	}
+	var doc Document
+	if err := decodeStrict(jsonData, &doc); err != nil {
+		return nil, fmt.Errorf("parse document: %w", err)
+	}
+	return &doc, nil
+}
+
+// Marshal renders a document as YAML
+func (d *Document) Marshal() ([]byte, error) {
+	jsonData, err := json.Marshal(d)
+	if err != nil {
+		return nil, err
+	}
+	var raw any
+	if err := json.Unmarshal(jsonData, &raw); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(raw); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), enc.Close()
+}
+
+// decodeStrict decodes JSON into v (over its current values), rejecting
+// unknown fields so typos in a document are reported rather than ignored
+func decodeStrict(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+// hasField reports whether a JSON object has the field
+func hasField(data []byte, field string) bool {
+	var obj map[string]json.RawMessage
+	if json.Unmarshal(data, &obj) != nil {
+		return false
+	}
+	_, ok := obj[field]
+	return ok
+}
+
+// entryName returns the field identifying an entry, for error messages and matching
+func entryName(data []byte, field string) (string, error) {
+	var obj map[string]any
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return "", fmt.Errorf("entry is not an object")
+	}
+	name, _ := obj[field].(string)
+	if name == "" {
+		return "", fmt.Errorf("entry has no %s", field)
+	}
+	return name, nil
+}
@@ -0,0 +1,579 @@
+package configsync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"modelgate/internal/domain"
+)
+
+// Store is the persistence used by the syncer (implemented by postgres.TenantStore)
+type Store interface {
+	ListRoles(ctx context.Context) ([]*domain.Role, error)
+	GetRolePolicy(ctx context.Context, roleID string) (*domain.RolePolicy, error)
+	CreateRole(ctx context.Context, role *domain.Role) error
+	UpdateRole(ctx context.Context, role *domain.Role) error
+	DeleteRole(ctx context.Context, id string) error
+	UpdateRolePolicy(ctx context.Context, policy *domain.RolePolicy) error
+	ListGroups(ctx context.Context) ([]*domain.Group, error)
+	CreateGroup(ctx context.Context, group *domain.Group) error
+	UpdateGroup(ctx context.Context, group *domain.Group) error
+	DeleteGroup(ctx context.Context, id string) error
+	ListProviderConfigs(ctx context.Context) ([]*domain.ProviderConfig, error)
+	SaveProviderConfig(ctx context.Context, config *domain.ProviderConfig) error
+}
+
+// Change kinds and actions
+const (
+	KindRole     = "role"
+	KindGroup    = "group"
+	KindProvider = "provider"
+
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// Change is a difference between the document and the database
+type Change struct {
+	Kind   string   `json:"kind"`
+	Name   string   `json:"name"`
+	ID     string   `json:"id,omitempty"` // Role or group ID; empty for providers
+	Action string   `json:"action"`
+	Fields []string `json:"fields,omitempty"` // Fields an update changes, e.g. policy.model_restrictions
+}
+
+func (c Change) String() string {
+	s := fmt.Sprintf("%s %s %q", c.Action, c.Kind, c.Name)
+	if len(c.Fields) > 0 {
+		s += " (" + strings.Join(c.Fields, ", ") + ")"
+	}
+	return s
+}
+
+// Options control an apply
+type Options struct {
+	DryRun bool // Report the changes without making them
+	Prune  bool // Delete roles and groups that are not in the document (system roles are kept)
+
+	// Recorded as the creator of new roles and groups
+	ActorID    string
+	ActorEmail string
+}
+
+// Syncer applies documents to the database
+type Syncer struct {
+	store Store
+}
+
+// NewSyncer creates a syncer
+func NewSyncer(store Store) *Syncer {
+	return &Syncer{store: store}
+}
+
+// step is a planned change and the write that makes it
+type step struct {
+	change Change
+	apply  func(ctx context.Context) error
+}
+
+// Apply compares the document with the database and makes the changes needed
+// for them to match, returning the changes in the order they were (or, with
+// DryRun, would be) made. Applying the same document again changes nothing.
+// The document is validated as a whole before anything is written; a write
+// failing part-way leaves the earlier changes in place.
+func (s *Syncer) Apply(ctx context.Context, doc *Document, opts Options) ([]Change, error) {
+	roles, err := s.store.ListRoles(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list roles: %w", err)
+	}
+	groups, err := s.store.ListGroups(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list groups: %w", err)
+	}
+	providers, err := s.store.ListProviderConfigs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list providers: %w", err)
+	}
+
+	var steps []step
+	roleSteps, roleIDs, err := s.planRoles(ctx, doc.Roles, roles, opts)
+	if err != nil {
+		return nil, err
+	}
+	steps = append(steps, roleSteps...)
+	groupSteps, err := s.planGroups(doc.Groups, groups, roleIDs, opts)
+	if err != nil {
+		return nil, err
+	}
+	steps = append(steps, groupSteps...)
+	providerSteps, err := s.planProviders(doc.Providers, providers)
+	if err != nil {
+		return nil, err
+	}
+	steps = append(steps, providerSteps...)
+	if opts.Prune {
+		steps = append(steps, s.planPrune(doc, roles, groups)...)
+	}
+
+	changes := make([]Change, 0, len(steps))
+	for _, st := range steps {
+		if !opts.DryRun {
+			if err := st.apply(ctx); err != nil {
+				return changes, fmt.Errorf("%s: %w", st.change, err)
+			}
+		}
+		changes = append(changes, st.change)
+	}
+	return changes, nil
+}
+
+// planRoles plans the role entries and returns the ID of every role by name,
+// including the roles the plan creates
+func (s *Syncer) planRoles(ctx context.Context, entries []json.RawMessage, existing []*domain.Role, opts Options) ([]step, map[string]string, error) {
+	byName := make(map[string]*domain.Role, len(existing))
+	ids := make(map[string]string, len(existing)+len(entries))
+	for _, r := range existing {
+		byName[r.Name] = r
+		ids[r.Name] = r.ID
+	}
+
+	var steps []step
+	seen := make(map[string]bool, len(entries))
+	for i, entry := range entries {
+		name, err := entryName(entry, "name")
+		if err != nil {
+			return nil, nil, fmt.Errorf("roles[%d]: %w", i, err)
+		}
+		if seen[name] {
+			return nil, nil, fmt.Errorf("roles[%d]: role %q is declared twice", i, name)
+		}
+		seen[name] = true
+		managesPolicy := hasField(entry, "policy")
+
+		current := byName[name]
+		var currentSpec roleSpec
+		roleID := uuid.New().String()
+		if current != nil {
+			roleID = current.ID
+			currentSpec = roleSpec{Name: current.Name, Description: current.Description, Permissions: current.Permissions, IsDefault: current.IsDefault}
+			if managesPolicy {
+				if currentSpec.Policy, err = s.store.GetRolePolicy(ctx, current.ID); err != nil {
+					return nil, nil, fmt.Errorf("get policy of role %q: %w", name, err)
+				}
+			}
+		}
+		ids[name] = roleID
+
+		desired := currentSpec
+		desired.Permissions = append([]string(nil), currentSpec.Permissions...)
+		if managesPolicy {
+			// Undeclared policy fields keep their current values, or the
+			// defaults for a role that has no policy yet
+			if currentSpec.Policy != nil {
+				desired.Policy = clonePolicy(currentSpec.Policy)
+			} else {
+				desired.Policy = domain.DefaultRolePolicy(roleID, name)
+			}
+		}
+		if err := decodeStrict(entry, &desired); err != nil {
+			return nil, nil, fmt.Errorf("role %q: %w", name, err)
+		}
+		if desired.Permissions == nil {
+			desired.Permissions = []string{}
+		}
+		if managesPolicy {
+			if desired.Policy == nil {
+				return nil, nil, fmt.Errorf("role %q: policy must be an object", name)
+			}
+			cidrs, err := domain.NormalizeCIDRs(desired.Policy.NetworkPolicy.AllowedCIDRs)
+			if err != nil {
+				return nil, nil, fmt.Errorf("role %q: policy.network_policy.allowed_cidrs: %w", name, err)
+			}
+			desired.Policy.NetworkPolicy.AllowedCIDRs = cidrs
+			if err := desired.Policy.GenerationPolicy.Validate(); err != nil {
+				return nil, nil, fmt.Errorf("role %q: policy.generation_policy: %w", name, err)
+			}
+			if err := desired.Policy.OutputTransform.Validate(); err != nil {
+				return nil, nil, fmt.Errorf("role %q: policy.output_transform_policy: %w", name, err)
+			}
+			desired.Policy.RoleID = roleID
+		}
+
+		role := &domain.Role{ID: roleID, Name: name, Description: desired.Description, Permissions: desired.Permissions, IsDefault: desired.IsDefault}
+		policy := desired.Policy
+		if current == nil {
+			role.CreatedBy, role.CreatedByEmail = opts.ActorID, opts.ActorEmail
+			steps = append(steps, step{
+				change: Change{Kind: KindRole, Name: name, ID: roleID, Action: ActionCreate},
+				apply: func(ctx context.Context) error {
+					if err := s.store.CreateRole(ctx, role); err != nil {
+						return err
+					}
+					if policy != nil {
+						return s.store.UpdateRolePolicy(ctx, policy)
+					}
+					return nil
+				},
+			})
+			continue
+		}
+
+		fields := diffFields(roleForDiff(currentSpec), roleForDiff(desired))
+		if len(fields) == 0 {
+			continue
+		}
+		updateRole, updatePolicy := false, false
+		for _, f := range fields {
+			if strings.HasPrefix(f, "policy") {
+				updatePolicy = true
+			} else {
+				updateRole = true
+			}
+		}
+		steps = append(steps, step{
+			change: Change{Kind: KindRole, Name: name, ID: roleID, Action: ActionUpdate, Fields: fields},
+			apply: func(ctx context.Context) error {
+				if updateRole {
+					if err := s.store.UpdateRole(ctx, role); err != nil {
+						return err
+					}
+				}
+				if updatePolicy {
+					return s.store.UpdateRolePolicy(ctx, policy)
+				}
+				return nil
+			},
+		})
+	}
+	return steps, ids, nil
+}
+
+// clonePolicy deep-copies a policy, so decoding a document over the copy
+// leaves the original's slices and maps alone
+func clonePolicy(p *domain.RolePolicy) *domain.RolePolicy {
+	var clone domain.RolePolicy
+	json.Unmarshal(mustMarshal(p), &clone)
+	return &clone
+}
+
+// roleForDiff returns the JSON form of a role entry without the policy's
+// identity and timestamps, which a document doesn't manage
+func roleForDiff(spec roleSpec) map[string]any {
+	m := toMap(spec)
+	if policy, ok := m["policy"].(map[string]any); ok {
+		for _, field := range []string{"id", "role_id", "created_at", "updated_at"} {
+			delete(policy, field)
+		}
+	}
+	return m
+}
+
+func (s *Syncer) planGroups(entries []json.RawMessage, existing []*domain.Group, roleIDs map[string]string, opts Options) ([]step, error) {
+	byName := make(map[string]*domain.Group, len(existing))
+	for _, g := range existing {
+		byName[g.Name] = g
+	}
+	roleNames := make(map[string]string, len(roleIDs))
+	for name, id := range roleIDs {
+		roleNames[id] = name
+	}
+
+	var steps []step
+	seen := make(map[string]bool, len(entries))
+	for i, entry := range entries {
+		name, err := entryName(entry, "name")
+		if err != nil {
+			return nil, fmt.Errorf("groups[%d]: %w", i, err)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("groups[%d]: group %q is declared twice", i, name)
+		}
+		seen[name] = true
+
+		current := byName[name]
+		currentSpec := groupSpec{Name: name, Roles: []string{}}
+		if current != nil {
+			currentSpec.Description = current.Description
+			for _, id := range current.RoleIDs {
+				currentSpec.Roles = append(currentSpec.Roles, roleNames[id])
+			}
+			sort.Strings(currentSpec.Roles)
+		}
+		desired := currentSpec
+		desired.Roles = append([]string(nil), currentSpec.Roles...)
+		if err := decodeStrict(entry, &desired); err != nil {
+			return nil, fmt.Errorf("group %q: %w", name, err)
+		}
+		sort.Strings(desired.Roles)
+
+		group := &domain.Group{Name: name, Description: desired.Description, RoleIDs: []string{}}
+		for _, roleName := range desired.Roles {
+			id, ok := roleIDs[roleName]
+			if !ok {
+				return nil, fmt.Errorf("group %q: role %q does not exist", name, roleName)
+			}
+			group.RoleIDs = append(group.RoleIDs, id)
+		}
+
+		if current == nil {
+			group.ID = uuid.New().String()
+			group.CreatedBy, group.CreatedByEmail = opts.ActorID, opts.ActorEmail
+			steps = append(steps, step{
+				change: Change{Kind: KindGroup, Name: name, ID: group.ID, Action: ActionCreate},
+				apply:  func(ctx context.Context) error { return s.store.CreateGroup(ctx, group) },
+			})
+			continue
+		}
+		fields := diffFields(toMap(currentSpec), toMap(desired))
+		if len(fields) == 0 {
+			continue
+		}
+		group.ID = current.ID
+		steps = append(steps, step{
+			change: Change{Kind: KindGroup, Name: name, ID: group.ID, Action: ActionUpdate, Fields: fields},
+			apply:  func(ctx context.Context) error { return s.store.UpdateGroup(ctx, group) },
+		})
+	}
+	return steps, nil
+}
+
+func (s *Syncer) planProviders(entries []json.RawMessage, existing []*domain.ProviderConfig) ([]step, error) {
+	byProvider := make(map[domain.Provider]*domain.ProviderConfig, len(existing))
+	for _, p := range existing {
+		byProvider[p.Provider] = p
+	}
+
+	var steps []step
+	seen := make(map[domain.Provider]bool, len(entries))
+	for i, entry := range entries {
+		name, err := entryName(entry, "provider")
+		if err != nil {
+			return nil, fmt.Errorf("providers[%d]: %w", i, err)
+		}
+		provider, ok := domain.ParseProvider(name)
+		if !ok {
+			return nil, fmt.Errorf("providers[%d]: unknown provider %q", i, name)
+		}
+		if seen[provider] {
+			return nil, fmt.Errorf("providers[%d]: provider %q is declared twice", i, provider)
+		}
+		seen[provider] = true
+		for _, secret := range []string{"api_key", "access_key_id", "secret_access_key"} {
+			if hasField(entry, secret) {
+				return nil, fmt.Errorf("provider %q: %s is not managed by config sync; use `modelgate provider set-key`", provider, secret)
+			}
+		}
+
+		current := byProvider[provider]
+		currentSpec := providerSpec{Provider: provider, ConnectionSettings: domain.DefaultConnectionSettings()}
+		if current != nil {
+			currentSpec = providerSpecFrom(current)
+		}
+		desired := currentSpec
+		desired.Throttles = append([]domain.ProviderThrottle(nil), currentSpec.Throttles...)
+		desired.Regions = append([]domain.ProviderRegion(nil), currentSpec.Regions...)
+		desired.HuggingFaceEndpoints = append([]domain.HuggingFaceEndpoint(nil), currentSpec.HuggingFaceEndpoints...)
+		if err := decodeStrict(entry, &desired); err != nil {
+			return nil, fmt.Errorf("provider %q: %w", provider, err)
+		}
+		desired.Provider = provider
+		cs := desired.ConnectionSettings
+		if cs.MaxIdleConnections > cs.MaxConnections {
+			return nil, fmt.Errorf("provider %q: idle connections (%d) cannot exceed max connections (%d)",
+				provider, cs.MaxIdleConnections, cs.MaxConnections)
+		}
+
+		config := &domain.ProviderConfig{}
+		if current != nil {
+			copied := *current
+			config = &copied
+		}
+		applyProviderSpec(config, desired)
+
+		action := ActionCreate
+		var fields []string
+		if current != nil {
+			action = ActionUpdate
+			if fields = diffFields(toMap(currentSpec), toMap(desired)); len(fields) == 0 {
+				continue
+			}
+		}
+		steps = append(steps, step{
+			change: Change{Kind: KindProvider, Name: string(provider), Action: action, Fields: fields},
+			apply:  func(ctx context.Context) error { return s.store.SaveProviderConfig(ctx, config) },
+		})
+	}
+	return steps, nil
+}
+
+func providerSpecFrom(c *domain.ProviderConfig) providerSpec {
+	return providerSpec{
+		Provider:           c.Provider,
+		Enabled:            c.Enabled,
+		BaseURL:            c.BaseURL,
+		Region:             c.Region,
+		RegionPrefix:       c.RegionPrefix,
+		ModelsURL:          c.ModelsURL,
+		ResourceName:       c.ResourceName,
+		APIVersion:         c.APIVersion,
+		ConnectionSettings: c.ConnectionSettings,
+		Throttles:          c.Throttles,
+		Regions:            c.Regions,
+		KeyStrategy:        c.KeyStrategy,
+
+		HuggingFaceEndpoints: c.HuggingFaceEndpoints,
+	}
+}
+
+func applyProviderSpec(c *domain.ProviderConfig, spec providerSpec) {
+	c.Provider = spec.Provider
+	c.Enabled = spec.Enabled
+	c.BaseURL = spec.BaseURL
+	c.Region = spec.Region
+	c.RegionPrefix = spec.RegionPrefix
+	c.ModelsURL = spec.ModelsURL
+	c.ResourceName = spec.ResourceName
+	c.APIVersion = spec.APIVersion
+	c.ConnectionSettings = spec.ConnectionSettings
+	c.Throttles = spec.Throttles
+	c.Regions = spec.Regions
+	c.KeyStrategy = spec.KeyStrategy
+	c.HuggingFaceEndpoints = spec.HuggingFaceEndpoints
+}
+
+// planPrune plans deleting the groups and non-system roles the document doesn't declare
+func (s *Syncer) planPrune(doc *Document, roles []*domain.Role, groups []*domain.Group) []step {
+	declared := make(map[string]bool)
+	for _, entry := range doc.Groups {
+		name, _ := entryName(entry, "name")
+		declared["group:"+name] = true
+	}
+	for _, entry := range doc.Roles {
+		name, _ := entryName(entry, "name")
+		declared["role:"+name] = true
+	}
+
+	var steps []step
+	for _, g := range groups {
+		if declared["group:"+g.Name] {
+			continue
+		}
+		id := g.ID
+		steps = append(steps, step{
+			change: Change{Kind: KindGroup, Name: g.Name, ID: id, Action: ActionDelete},
+			apply:  func(ctx context.Context) error { return s.store.DeleteGroup(ctx, id) },
+		})
+	}
+	for _, r := range roles {
+		if r.IsSystem || declared["role:"+r.Name] {
+			continue
+		}
+		id := r.ID
+		steps = append(steps, step{
+			change: Change{Kind: KindRole, Name: r.Name, ID: id, Action: ActionDelete},
+			apply:  func(ctx context.Context) error { return s.store.DeleteRole(ctx, id) },
+		})
+	}
+	return steps
+}
+
+// Export describes the database as a document: every role with its policy,
+// every group and every configured provider
+func (s *Syncer) Export(ctx context.Context) (*Document, error) {
+	roles, err := s.store.ListRoles(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list roles: %w", err)
+	}
+	groups, err := s.store.ListGroups(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list groups: %w", err)
+	}
+	providers, err := s.store.ListProviderConfigs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list providers: %w", err)
+	}
+
+	doc := &Document{}
+	roleNames := make(map[string]string, len(roles))
+	for _, r := range roles {
+		roleNames[r.ID] = r.Name
+		spec := roleSpec{Name: r.Name, Description: r.Description, Permissions: r.Permissions, IsDefault: r.IsDefault}
+		if spec.Policy, err = s.store.GetRolePolicy(ctx, r.ID); err != nil {
+			return nil, fmt.Errorf("get policy of role %q: %w", r.Name, err)
+		}
+		doc.Roles = append(doc.Roles, mustMarshal(roleForDiff(spec)))
+	}
+	for _, g := range groups {
+		spec := groupSpec{Name: g.Name, Description: g.Description, Roles: []string{}}
+		for _, id := range g.RoleIDs {
+			spec.Roles = append(spec.Roles, roleNames[id])
+		}
+		sort.Strings(spec.Roles)
+		doc.Groups = append(doc.Groups, mustMarshal(spec))
+	}
+	for _, p := range providers {
+		doc.Providers = append(doc.Providers, mustMarshal(providerSpecFrom(p)))
+	}
+	return doc, nil
+}
+
+// diffFields compares two entries in their JSON form and returns the paths
+// of the fields that differ, down to the second level (e.g. policy.budget_policy)
+func diffFields(current, desired map[string]any) []string {
+	var fields []string
+	for _, key := range unionKeys(current, desired) {
+		a, b := current[key], desired[key]
+		if reflect.DeepEqual(a, b) {
+			continue
+		}
+		am, aok := a.(map[string]any)
+		bm, bok := b.(map[string]any)
+		if !aok || !bok {
+			fields = append(fields, key)
+			continue
+		}
+		for _, sub := range unionKeys(am, bm) {
+			if !reflect.DeepEqual(am[sub], bm[sub]) {
+				fields = append(fields, key+"."+sub)
+			}
+		}
+	}
+	return fields
+}
+
+func unionKeys(a, b map[string]any) []string {
+	keys := make([]string, 0, len(a))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// toMap returns the JSON form of v as a map
+func toMap(v any) map[string]any {
+	var m map[string]any
+	json.Unmarshal(mustMarshal(v), &m)
+	return m
+}
+
+// mustMarshal marshals values that always marshal (structs of plain fields)
+func mustMarshal(v any) json.RawMessage {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("configsync: marshal %T: %v", v, err))
+	}
+	return data
+}
@@ -0,0 +1,215 @@
+package configsync
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"modelgate/internal/domain"
+)
+
+type fakeStore struct {
+	roles     map[string]*domain.Role
+	policies  map[string]*domain.RolePolicy
+	groups    map[string]*domain.Group
+	providers map[domain.Provider]*domain.ProviderConfig
+	writes    int
+}
+
+func (f *fakeStore) ListRoles(ctx context.Context) ([]*domain.Role, error) {
+	var roles []*domain.Role
+	for _, r := range f.roles {
+		copied := *r
+		roles = append(roles, &copied)
+	}
+	return roles, nil
+}
+
+func (f *fakeStore) GetRolePolicy(ctx context.Context, roleID string) (*domain.RolePolicy, error) {
+	if p, ok := f.policies[roleID]; ok {
+		return clonePolicy(p), nil
+	}
+	return nil, nil
+}
+
+func (f *fakeStore) CreateRole(ctx context.Context, role *domain.Role) error {
+	f.writes++
+	f.roles[role.ID] = role
+	return nil
+}
+
+func (f *fakeStore) UpdateRole(ctx context.Context, role *domain.Role) error {
+	f.writes++
+	f.roles[role.ID] = role
+	return nil
+}
+
+func (f *fakeStore) DeleteRole(ctx context.Context, id string) error {
+	f.writes++
+	delete(f.roles, id)
+	return nil
+}
+
+func (f *fakeStore) UpdateRolePolicy(ctx context.Context, policy *domain.RolePolicy) error {
+	f.writes++
+	f.policies[policy.RoleID] = policy
+	return nil
+}
+
+func (f *fakeStore) ListGroups(ctx context.Context) ([]*domain.Group, error) {
+	var groups []*domain.Group
+	for _, g := range f.groups {
+		copied := *g
+		groups = append(groups, &copied)
+	}
+	return groups, nil
+}
+
+func (f *fakeStore) CreateGroup(ctx context.Context, group *domain.Group) error {
+	f.writes++
+	f.groups[group.ID] = group
+	return nil
+}
+
+func (f *fakeStore) UpdateGroup(ctx context.Context, group *domain.Group) error {
+	f.writes++
+	f.groups[group.ID] = group
+	return nil
+}
+
+func (f *fakeStore) DeleteGroup(ctx context.Context, id string) error {
+	f.writes++
+	delete(f.groups, id)
+	return nil
+}
+
+func (f *fakeStore) ListProviderConfigs(ctx context.Context) ([]*domain.ProviderConfig, error) {
+	var configs []*domain.ProviderConfig
+	for _, c := range f.providers {
+		copied := *c
+		configs = append(configs, &copied)
+	}
+	return configs, nil
+}
+
+func (f *fakeStore) SaveProviderConfig(ctx context.Context, config *domain.ProviderConfig) error {
+	f.writes++
+	f.providers[config.Provider] = config
+	return nil
+}
+
+const testDocument = `
+roles:
+  - name: support
+    description: Support bots
+    permissions: [chat:create]
+    policy:
+      model_restrictions:
+        allowed_models: [gpt-4o-mini]
+        default_model: gpt-4o-mini
+      budget_policy:
+        enabled: true
+        monthly_limit_usd: 300
+  - name: admin
+    description: Full access
+groups:
+  - name: helpdesk
+    roles: [support]
+providers:
+  - provider: openai
+    enabled: true
+    connection_settings:
+      max_connections: 20
+`
+
+func TestApply(t *testing.T) {
+	ctx := context.Background()
+	store := &fakeStore{
+		roles: map[string]*domain.Role{
+			"r-admin": {ID: "r-admin", Name: "admin", Description: "Admins", Permissions: []string{"*"}, IsSystem: true},
+			"r-old":   {ID: "r-old", Name: "legacy", Permissions: []string{}},
+		},
+		policies:  map[string]*domain.RolePolicy{},
+		groups:    map[string]*domain.Group{},
+		providers: map[domain.Provider]*domain.ProviderConfig{},
+	}
+	syncer := NewSyncer(store)
+	doc, err := Parse([]byte(testDocument))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	// A dry run reports the changes without writing
+	changes, err := syncer.Apply(ctx, doc, Options{DryRun: true, Prune: true})
+	if err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+	var got []string
+	for _, c := range changes {
+		got = append(got, c.String())
+	}
+	want := []string{
+		`create role "support"`,
+		`update role "admin" (description)`,
+		`create group "helpdesk"`,
+		`create provider "openai"`,
+		`delete role "legacy"`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected plan:\n%s", strings.Join(got, "\n"))
+	}
+	if store.writes != 0 {
+		t.Fatalf("dry run wrote %d times", store.writes)
+	}
+
+	if _, err := syncer.Apply(ctx, doc, Options{Prune: true}); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	var support *domain.Role
+	for _, r := range store.roles {
+		if r.Name == "support" {
+			support = r
+		}
+	}
+	if support == nil || store.roles["r-admin"].Permissions[0] != "*" || store.roles["r-old"] != nil {
+		t.Fatalf("unexpected roles %+v", store.roles)
+	}
+	policy := store.policies[support.ID]
+	if policy == nil || policy.ModelRestriction.DefaultModel != "gpt-4o-mini" || policy.BudgetPolicy.MonthlyLimitUSD != 300 ||
+		!policy.PromptPolicies.InputBounds.Enabled {
+		t.Fatalf("expected the declared policy over the defaults, got %+v", policy)
+	}
+	if p := store.providers[domain.ProviderOpenAI]; p == nil || !p.Enabled || p.ConnectionSettings.MaxConnections != 20 ||
+		p.ConnectionSettings.RequestTimeoutSec == 0 {
+		t.Fatalf("unexpected provider config %+v", p)
+	}
+
+	// Applying again is a no-op
+	writes := store.writes
+	changes, err = syncer.Apply(ctx, doc, Options{Prune: true})
+	if err != nil || len(changes) != 0 || store.writes != writes {
+		t.Fatalf("expected a second apply to change nothing, got %v, %v", changes, err)
+	}
+
+	// A changed policy field is reported by path
+	doc, _ = Parse([]byte(strings.Replace(testDocument, "monthly_limit_usd: 300", "monthly_limit_usd: 500", 1)))
+	changes, err = syncer.Apply(ctx, doc, Options{DryRun: true})
+	if err != nil || len(changes) != 1 || !reflect.DeepEqual(changes[0].Fields, []string{"policy.budget_policy"}) {
+		t.Fatalf("expected a budget policy update, got %v, %v", changes, err)
+	}
+
+	for _, bad := range []string{
+		"roles:\n  - name: x\n    permisions: []\n",
+		"groups:\n  - name: g\n    roles: [missing]\n",
+		"providers:\n  - provider: openai\n    api_key: sk-123\n",
+	} {
+		doc, err := Parse([]byte(bad))
+		if err == nil {
+			_, err = syncer.Apply(ctx, doc, Options{DryRun: true})
+		}
+		if err == nil {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+// Package convbudget enforces the per-conversation ceilings of role budgets,
+// so an agent stuck in a loop can't run up an unbounded bill in one
+// conversation. Conversations are identified the way sticky routing
+// identifies them (routing.ConversationKey); their running totals are kept in
+// memory on each instance and forgotten once a conversation has been idle
+// for a day.
+package convbudget
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+const (
+	// idleTTL is how long a conversation's totals are kept after its last turn
+	idleTTL = 24 * time.Hour
+
+	// maxConversations bounds the conversations tracked per instance; when
+	// full, the one idle longest is dropped
+	maxConversations = 100_000
+)
+
+// Spend is what a conversation's turns have used so far
+type Spend struct {
+	Tokens  int64   `json:"tokens"`
+	CostUSD float64 `json:"cost_usd"`
+	Turns   int     `json:"turns"`
+}
+
+// Check is the outcome of checking a turn against its conversation's ceilings
+type Check struct {
+	Allowed bool
+	Reason  string // Set when the turn is refused
+	Limit   string // "tokens" or "cost" when the turn is refused
+	Spend   Spend
+}
+
+// Alert is the payload posted to a budget policy's alert webhook
+type Alert struct {
+	Type            string    `json:"type"`  // "conversation_budget_warning" or "conversation_budget_exceeded"
+	Limit           string    `json:"limit"` // "tokens" or "cost"
+	RoleID          string    `json:"role_id"`
+	APIKeyID        string    `json:"api_key_id,omitempty"`
+	ConversationKey string    `json:"conversation_key"`
+	LimitValue      float64   `json:"limit_value"`
+	Spent           float64   `json:"spent"`
+	Turns           int       `json:"turns"`
+	At              time.Time `json:"at"`
+}
+
+type conversation struct {
+	spend    Spend
+	lastTurn time.Time
+	alerted  map[string]bool // Alert type and limit
+}
+
+// Service tracks conversation spend and checks turns against role ceilings
+type Service struct {
+	httpClient *http.Client
+	now        func() time.Time
+
+	mu            sync.Mutex
+	conversations map[string]*conversation
+}
+
+// NewService creates a new conversation budget service
+func NewService() *Service {
+	return &Service{
+		httpClient:    &http.Client{Timeout: 10 * time.Second},
+		now:           time.Now,
+		conversations: make(map[string]*conversation),
+	}
+}
+
+// Check checks whether another turn of the conversation may proceed under
+// the policy's ceilings. A conversation that reached a ceiling is refused;
+// one past the policy's alert threshold of a ceiling is let through. Each
+// alerts the policy's webhook and Slack channel once per conversation.
+func (s *Service) Check(ctx context.Context, key string, req *domain.ChatRequest, policy domain.BudgetPolicy) *Check {
+	if key == "" || !policy.HasConversationCeiling() {
+		return &Check{Allowed: true}
+	}
+
+	now := s.now()
+	s.mu.Lock()
+	c := s.conversations[key]
+	if c != nil && now.Sub(c.lastTurn) > idleTTL {
+		delete(s.conversations, key)
+		c = nil
+	}
+	var spend Spend
+	if c != nil {
+		spend = c.spend
+	}
+	s.mu.Unlock()
+
+	check := &Check{Allowed: true, Spend: spend}
+	ceilings := []struct {
+		name         string
+		limit, spent float64
+	}{
+		{"tokens", float64(policy.MaxTokensPerConversation), float64(spend.Tokens)},
+		{"cost", policy.MaxCostPerConversation, spend.CostUSD},
+	}
+	for _, ceiling := range ceilings {
+		if ceiling.limit <= 0 {
+			continue
+		}
+		switch {
+		case ceiling.spent >= ceiling.limit:
+			check.Allowed = false
+			check.Limit = ceiling.name
+			check.Reason = exceededMessage(ceiling.name, ceiling.limit, spend)
+			s.alert(ctx, key, "conversation_budget_exceeded", ceiling.name, ceiling.limit, ceiling.spent, spend.Turns, req, policy)
+			return check
+		case policy.AlertThreshold > 0 && ceiling.spent >= ceiling.limit*policy.AlertThreshold:
+			s.alert(ctx, key, "conversation_budget_warning", ceiling.name, ceiling.limit, ceiling.spent, spend.Turns, req, policy)
+		}
+	}
+	return check
+}
+
+func exceededMessage(limit string, ceiling float64, spend Spend) string {
+	if limit == "tokens" {
+		return fmt.Sprintf("conversation used %d tokens over %d turns, reaching its role's ceiling of %d tokens per conversation; start a new conversation",
+			spend.Tokens, spend.Turns, int64(ceiling))
+	}
+	return fmt.Sprintf("conversation cost $%.4f over %d turns, reaching its role's ceiling of $%.2f per conversation; start a new conversation",
+		spend.CostUSD, spend.Turns, ceiling)
+}
+
+// Record adds a finished turn's tokens and cost to its conversation's total
+func (s *Service) Record(key string, tokens int64, costUSD float64) {
+	if key == "" {
+		return
+	}
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.conversations[key]
+	if !ok || now.Sub(c.lastTurn) > idleTTL {
+		if !ok && len(s.conversations) >= maxConversations {
+			s.evict(now)
+		}
+		c = &conversation{}
+		s.conversations[key] = c
+	}
+	c.spend.Tokens += tokens
+	c.spend.CostUSD += costUSD
+	c.spend.Turns++
+	c.lastTurn = now
+}
+
+// Spend returns a conversation's totals so far
+func (s *Service) Spend(key string) Spend {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, ok := s.conversations[key]; ok && s.now().Sub(c.lastTurn) <= idleTTL {
+		return c.spend
+	}
+	return Spend{}
+}
+
+// evict drops idle conversations, or the one idle longest when none is
+func (s *Service) evict(now time.Time) {
+	var oldestKey string
+	var oldest time.Time
+	for key, c := range s.conversations {
+		if now.Sub(c.lastTurn) > idleTTL {
+			delete(s.conversations, key)
+			continue
+		}
+		if oldestKey == "" || c.lastTurn.Before(oldest) {
+			oldestKey, oldest = key, c.lastTurn
+		}
+	}
+	if len(s.conversations) >= maxConversations && oldestKey != "" {
+		delete(s.conversations, oldestKey)
+	}
+}
+
+// alert sends a ceiling event to the policy's webhook and Slack channel, once
+// per conversation, event and ceiling. Failures are logged; the alert is not
+// retried.
+func (s *Service) alert(ctx context.Context, key, alertType, limit string, ceiling, spent float64, turns int, req *domain.ChatRequest, policy domain.BudgetPolicy) {
+	s.mu.Lock()
+	c := s.conversations[key]
+	dedupe := alertType + ":" + limit
+	if c == nil || c.alerted[dedupe] {
+		s.mu.Unlock()
+		return
+	}
+	if c.alerted == nil {
+		c.alerted = make(map[string]bool)
+	}
+	c.alerted[dedupe] = true
+	s.mu.Unlock()
+
+	slog.Warn("Conversation budget ceiling", "type", alertType, "limit", limit, "ceiling", ceiling, "spent", spent,
+		"turns", turns, "role_id", req.RoleID, "api_key_id", req.APIKeyID, "request_id", req.RequestID)
+
+	payload := Alert{
+		Type:            alertType,
+		Limit:           limit,
+		RoleID:          req.RoleID,
+		APIKeyID:        req.APIKeyID,
+		ConversationKey: key,
+		LimitValue:      ceiling,
+		Spent:           spent,
+		Turns:           turns,
+		At:              s.now(),
+	}
+	summary := fmt.Sprintf("Conversation of API key %s reached %.0f%% of its role's %s ceiling (%g of %g over %d turns)",
+		req.APIKeyID, spent/ceiling*100, limit, spent, ceiling, turns)
+	if alertType == "conversation_budget_exceeded" {
+		summary = fmt.Sprintf("Conversation of API key %s reached its role's %s ceiling (%g of %g over %d turns); further turns are refused",
+			req.APIKeyID, limit, spent, ceiling, turns)
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	if url := policy.AlertWebhook; url != "" {
+		go func() {
+			if err := s.post(ctx, url, payload); err != nil {
+				slog.Error("Failed to send conversation budget alert", "type", alertType, "error", err)
+			}
+		}()
+	}
+	if url := policy.AlertSlack; url != "" {
+		go func() {
+			if err := s.post(ctx, url, map[string]string{"text": summary}); err != nil {
+				slog.Error("Failed to send conversation budget alert to Slack", "type", alertType, "error", err)
+			}
+		}()
+	}
+}
+
+func (s *Service) post(ctx context.Context, url string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
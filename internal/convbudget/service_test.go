@@ -0,0 +1,89 @@
+package convbudget
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+func TestConversationCeiling(t *testing.T) {
+	alerts := make(chan Alert, 4)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var a Alert
+		json.NewDecoder(r.Body).Decode(&a)
+		alerts <- a
+	}))
+	defer webhook.Close()
+
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	svc := NewService()
+	svc.now = func() time.Time { return now }
+
+	policy := domain.BudgetPolicy{Enabled: true, MaxTokensPerConversation: 1000, AlertThreshold: 0.8, AlertWebhook: webhook.URL}
+	req := &domain.ChatRequest{RoleID: "r1", APIKeyID: "k1"}
+	ctx := context.Background()
+
+	// A new conversation is allowed
+	if check := svc.Check(ctx, "conv", req, policy); !check.Allowed {
+		t.Fatalf("expected the first turn to be allowed, got %+v", check)
+	}
+
+	// Past the alert threshold turns are still allowed, with one warning
+	svc.Record("conv", 850, 0.01)
+	if check := svc.Check(ctx, "conv", req, policy); !check.Allowed || check.Spend.Tokens != 850 || check.Spend.Turns != 1 {
+		t.Fatalf("expected the turn to be allowed with 850 tokens spent, got %+v", check)
+	}
+	if a := <-alerts; a.Type != "conversation_budget_warning" || a.Limit != "tokens" || a.Spent != 850 || a.RoleID != "r1" {
+		t.Fatalf("unexpected warning alert %+v", a)
+	}
+
+	// At the ceiling turns are refused
+	svc.Record("conv", 200, 0.01)
+	check := svc.Check(ctx, "conv", req, policy)
+	if check.Allowed || check.Limit != "tokens" || check.Reason == "" {
+		t.Fatalf("expected the turn to be refused on tokens, got %+v", check)
+	}
+	if a := <-alerts; a.Type != "conversation_budget_exceeded" || a.Turns != 2 {
+		t.Fatalf("unexpected exceeded alert %+v", a)
+	}
+
+	// Each alert is sent once per conversation
+	svc.Check(ctx, "conv", req, policy)
+	select {
+	case a := <-alerts:
+		t.Fatalf("unexpected repeated alert %+v", a)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Other conversations have their own totals
+	if check := svc.Check(ctx, "other", req, policy); !check.Allowed {
+		t.Fatalf("expected another conversation to be allowed, got %+v", check)
+	}
+
+	// A conversation idle for longer than a day starts over
+	now = now.Add(25 * time.Hour)
+	if check := svc.Check(ctx, "conv", req, policy); !check.Allowed || check.Spend.Tokens != 0 {
+		t.Fatalf("expected an idle conversation to start over, got %+v", check)
+	}
+
+	// The cost ceiling applies on its own
+	svc.Record("paid", 10, 2.5)
+	cost := domain.BudgetPolicy{Enabled: true, MaxCostPerConversation: 2}
+	if check := svc.Check(ctx, "paid", req, cost); check.Allowed || check.Limit != "cost" {
+		t.Fatalf("expected the turn to be refused on cost, got %+v", check)
+	}
+
+	// Without ceilings, or with the policy disabled, nothing is enforced
+	if check := svc.Check(ctx, "paid", req, domain.BudgetPolicy{Enabled: true}); !check.Allowed {
+		t.Fatalf("expected no ceiling to allow the turn, got %+v", check)
+	}
+	cost.Enabled = false
+	if check := svc.Check(ctx, "paid", req, cost); !check.Allowed {
+		t.Fatalf("expected a disabled policy to allow the turn, got %+v", check)
+	}
+}
@@ -0,0 +1,65 @@
+package crypto
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// sealedContentPrefix marks a value encrypted with a ContentCipher. The key ID
+// of the data key follows, then the base64 ciphertext.
+const sealedContentPrefix = "enc:v1:"
+
+// ContentCipher encrypts captured prompts and responses with a tenant's data
+// key. Sealed values are plain strings so they still fit JSON and JSONB columns.
+type ContentCipher struct {
+	dek *EncryptionService
+}
+
+// NewContentCipher creates a content cipher for a tenant data key
+func NewContentCipher(dataKey []byte) (*ContentCipher, error) {
+	dek, err := NewEncryptionService(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return &ContentCipher{dek: dek}, nil
+}
+
+// KeyID returns the identifier of the data key written into sealed values
+func (c *ContentCipher) KeyID() string {
+	return c.dek.KeyID()
+}
+
+// Seal encrypts plaintext into a sealed string
+func (c *ContentCipher) Seal(plaintext []byte) (string, error) {
+	ciphertext, err := c.dek.EncryptBytes(plaintext)
+	if err != nil {
+		return "", err
+	}
+	return sealedContentPrefix + c.dek.KeyID() + ":" + base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// Open decrypts a value produced by Seal
+func (c *ContentCipher) Open(sealed string) ([]byte, error) {
+	rest, ok := strings.CutPrefix(sealed, sealedContentPrefix)
+	if !ok {
+		return nil, ErrInvalidCiphertext
+	}
+	keyID, encoded, ok := strings.Cut(rest, ":")
+	if !ok {
+		return nil, ErrInvalidCiphertext
+	}
+	if keyID != c.dek.KeyID() {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, keyID)
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode ciphertext: %w", err)
+	}
+	return c.dek.DecryptBytes(ciphertext)
+}
+
+// IsSealed reports whether a value was produced by ContentCipher.Seal
+func IsSealed(value string) bool {
+	return strings.HasPrefix(value, sealedContentPrefix)
+}
@@ -0,0 +1,40 @@
+package crypto
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestContentCipherRoundTrip(t *testing.T) {
+	key, _ := GenerateKey(32)
+	c, err := NewContentCipher(key)
+	if err != nil {
+		t.Fatalf("NewContentCipher failed: %v", err)
+	}
+
+	sealed, err := c.Seal([]byte(`"what is our refund policy?"`))
+	if err != nil {
+		t.Fatalf("Seal failed: %v", err)
+	}
+	if !IsSealed(sealed) || strings.Contains(sealed, "refund") {
+		t.Fatalf("expected an opaque sealed value, got %q", sealed)
+	}
+	plain, err := c.Open(sealed)
+	if err != nil || string(plain) != `"what is our refund policy?"` {
+		t.Fatalf("Open = %q, %v", plain, err)
+	}
+
+	if IsSealed("plain prompt") {
+		t.Error("plain text reported as sealed")
+	}
+	if _, err := c.Open("plain prompt"); !errors.Is(err, ErrInvalidCiphertext) {
+		t.Errorf("expected ErrInvalidCiphertext, got %v", err)
+	}
+
+	otherKey, _ := GenerateKey(32)
+	other, _ := NewContentCipher(otherKey)
+	if _, err := other.Open(sealed); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("expected ErrUnknownKey, got %v", err)
+	}
+}
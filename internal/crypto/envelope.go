@@ -0,0 +1,150 @@
+package crypto
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// EnvelopeVersion is the format version written into new envelopes
+const EnvelopeVersion = 1
+
+// ErrUnknownKey is returned when an envelope was sealed with a key that isn't in the keyring
+var ErrUnknownKey = errors.New("envelope was encrypted with an unknown key")
+
+// Envelope is a value encrypted with its own random data key, which is in turn
+// encrypted with a master key. Rotating the master key only re-wraps the data key.
+type Envelope struct {
+	Version    int    `json:"version"`
+	KeyID      string `json:"key_id"`
+	DataKey    string `json:"data_key"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+// Keyring holds the master key used to seal new envelopes and any previous
+// master keys that are still accepted when opening old ones
+type Keyring struct {
+	primary *EncryptionService
+	keys    map[string]*EncryptionService
+}
+
+// NewKeyring creates a keyring that seals with primary and can also open
+// envelopes sealed with any of the previous keys
+func NewKeyring(primary *EncryptionService, previous ...*EncryptionService) *Keyring {
+	k := &Keyring{primary: primary, keys: map[string]*EncryptionService{primary.KeyID(): primary}}
+	for _, p := range previous {
+		if _, ok := k.keys[p.KeyID()]; !ok {
+			k.keys[p.KeyID()] = p
+		}
+	}
+	return k
+}
+
+// NewKeyringFromStrings creates a keyring from a base64-encoded primary key and
+// a comma-separated list of base64-encoded previous keys (may be empty)
+func NewKeyringFromStrings(primary, previous string) (*Keyring, error) {
+	svc, err := NewEncryptionServiceFromString(primary)
+	if err != nil {
+		return nil, err
+	}
+	var older []*EncryptionService
+	for _, encoded := range strings.Split(previous, ",") {
+		if encoded = strings.TrimSpace(encoded); encoded == "" {
+			continue
+		}
+		p, err := NewEncryptionServiceFromString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("previous key %d: %w", len(older)+1, err)
+		}
+		older = append(older, p)
+	}
+	return NewKeyring(svc, older...), nil
+}
+
+// PrimaryKeyID returns the identifier of the key new envelopes are sealed with
+func (k *Keyring) PrimaryKeyID() string {
+	return k.primary.KeyID()
+}
+
+// Seal encrypts plaintext under a fresh data key wrapped with the primary key
+func (k *Keyring) Seal(plaintext []byte) (*Envelope, error) {
+	dataKey, err := GenerateKey(32)
+	if err != nil {
+		return nil, err
+	}
+	dek, err := NewEncryptionService(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := dek.EncryptBytes(plaintext)
+	if err != nil {
+		return nil, err
+	}
+	wrapped, err := k.primary.EncryptBytes(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return &Envelope{
+		Version:    EnvelopeVersion,
+		KeyID:      k.primary.KeyID(),
+		DataKey:    base64.StdEncoding.EncodeToString(wrapped),
+		Ciphertext: base64.StdEncoding.EncodeToString(ciphertext),
+	}, nil
+}
+
+// Open decrypts an envelope sealed with any key in the keyring
+func (k *Keyring) Open(env *Envelope) ([]byte, error) {
+	dek, err := k.dataKey(env)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(env.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode ciphertext: %w", err)
+	}
+	return dek.DecryptBytes(ciphertext)
+}
+
+// Rewrap re-encrypts the envelope's data key with the primary key, leaving the
+// ciphertext untouched. It reports whether anything changed.
+func (k *Keyring) Rewrap(env *Envelope) (bool, error) {
+	if env.KeyID == k.primary.KeyID() {
+		return false, nil
+	}
+	master, ok := k.keys[env.KeyID]
+	if !ok {
+		return false, fmt.Errorf("%w: %s", ErrUnknownKey, env.KeyID)
+	}
+	dataKey, err := unwrapDataKey(master, env.DataKey)
+	if err != nil {
+		return false, err
+	}
+	wrapped, err := k.primary.EncryptBytes(dataKey)
+	if err != nil {
+		return false, err
+	}
+	env.KeyID = k.primary.KeyID()
+	env.DataKey = base64.StdEncoding.EncodeToString(wrapped)
+	return true, nil
+}
+
+func (k *Keyring) dataKey(env *Envelope) (*EncryptionService, error) {
+	master, ok := k.keys[env.KeyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, env.KeyID)
+	}
+	dataKey, err := unwrapDataKey(master, env.DataKey)
+	if err != nil {
+		return nil, err
+	}
+	return NewEncryptionService(dataKey)
+}
+
+func unwrapDataKey(master *EncryptionService, encoded string) ([]byte, error) {
+	wrapped, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode data key: %w", err)
+	}
+	return master.DecryptBytes(wrapped)
+}
@@ -0,0 +1,50 @@
+package crypto
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestKeyringRotation(t *testing.T) {
+	oldKey, _ := GenerateKeyString(32)
+	newKey, _ := GenerateKeyString(32)
+
+	before, err := NewKeyringFromStrings(oldKey, "")
+	if err != nil {
+		t.Fatalf("NewKeyringFromStrings failed: %v", err)
+	}
+	env, err := before.Seal([]byte(`{"bearer_token":"secret"}`))
+	if err != nil {
+		t.Fatalf("Seal failed: %v", err)
+	}
+	if env.KeyID != before.PrimaryKeyID() || env.Ciphertext == "" || env.DataKey == "" {
+		t.Fatalf("unexpected envelope %+v", env)
+	}
+
+	// Without the old key the envelope can't be opened
+	fresh, _ := NewKeyringFromStrings(newKey, "")
+	if _, err := fresh.Open(env); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("expected ErrUnknownKey, got %v", err)
+	}
+
+	after, err := NewKeyringFromStrings(newKey, " "+oldKey+",")
+	if err != nil {
+		t.Fatalf("NewKeyringFromStrings failed: %v", err)
+	}
+	ciphertext := env.Ciphertext
+	changed, err := after.Rewrap(env)
+	if err != nil || !changed {
+		t.Fatalf("expected the data key to be re-wrapped, got %v, %v", changed, err)
+	}
+	if env.KeyID != after.PrimaryKeyID() || env.Ciphertext != ciphertext {
+		t.Fatalf("expected only the data key to change, got %+v", env)
+	}
+	if changed, _ := after.Rewrap(env); changed {
+		t.Fatal("expected no change for an envelope already on the primary key")
+	}
+
+	plain, err := fresh.Open(env)
+	if err != nil || string(plain) != `{"bearer_token":"secret"}` {
+		t.Fatalf("expected the new key alone to open the envelope, got %q, %v", plain, err)
+	}
+}
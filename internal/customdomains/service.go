@@ -0,0 +1,434 @@
+// Package customdomains serves tenants on their own hostnames. Each
+// registered domain resolves requests to its tenant and carries its TLS
+// certificate, either uploaded by an admin or issued and renewed through
+// ACME. Hostnames that aren't registered can be refused.
+package customdomains
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// refreshInterval bounds how long a domain added on another instance takes
+// to be served here
+const refreshInterval = time.Minute
+
+var (
+	ErrNotFound           = errors.New("custom domain not found")
+	ErrDuplicate          = errors.New("hostname is already registered")
+	ErrInvalidHostname    = errors.New("hostname must be a domain name without scheme, port or path")
+	ErrUnknownTenant      = errors.New("tenant not found")
+	ErrACMEDisabled       = errors.New("ACME is not configured; upload a certificate or set custom_domains.acme_email")
+	ErrInvalidCertificate = errors.New("invalid certificate")
+)
+
+// Store is the persistence used by the service (implemented by postgres.TenantStore)
+type Store interface {
+	ListCustomDomains(ctx context.Context) ([]*domain.CustomDomain, error)
+	CreateCustomDomain(ctx context.Context, d *domain.CustomDomain) (bool, error)
+	UpdateCustomDomain(ctx context.Context, d *domain.CustomDomain) (bool, error)
+	DeleteCustomDomain(ctx context.Context, id string) (bool, error)
+	GetACMECache(ctx context.Context, key string) ([]byte, error)
+	PutACMECache(ctx context.Context, key string, data []byte) error
+	DeleteACMECache(ctx context.Context, key string) error
+}
+
+// Tenants lists the tenants a domain can resolve to (implemented by
+// domain.TenantRepository)
+type Tenants interface {
+	List(ctx context.Context, filter domain.TenantFilter) ([]*domain.Tenant, string, error)
+}
+
+// entry is a registered domain and its parsed uploaded certificate
+type entry struct {
+	domain *domain.CustomDomain
+	cert   *tls.Certificate // nil for ACME domains
+}
+
+// Service resolves hostnames from a cached copy of the stored domains and
+// hands out their certificates
+type Service struct {
+	cfg     config.CustomDomainsConfig
+	store   Store
+	tenants Tenants
+	acme    *autocert.Manager // nil when ACME is off
+	now     func() time.Time
+
+	mu    sync.RWMutex
+	hosts map[string]*entry // Hostname -> domain
+}
+
+// NewService creates a custom domain service. ACME is used only when
+// acme_email is set.
+func NewService(cfg config.CustomDomainsConfig, store Store, tenants Tenants) *Service {
+	s := &Service{
+		cfg:     cfg,
+		store:   store,
+		tenants: tenants,
+		now:     time.Now,
+		hosts:   make(map[string]*entry),
+	}
+	if cfg.ACMEEmail != "" {
+		s.acme = &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			Email:      cfg.ACMEEmail,
+			Cache:      acmeCache{store},
+			HostPolicy: s.acmeHostPolicy,
+		}
+		if cfg.ACMEDirectory != "" {
+			s.acme.Client = &acme.Client{DirectoryURL: cfg.ACMEDirectory}
+		}
+	}
+	return s
+}
+
+// Start loads the domains, then reloads them every refreshInterval until ctx
+// is cancelled
+func (s *Service) Start(ctx context.Context) {
+	if err := s.refresh(ctx); err != nil {
+		slog.Warn("Failed to load custom domains", "error", err)
+	}
+	go func() {
+		ticker := time.NewTicker(refreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.refresh(ctx); err != nil {
+					slog.Warn("Failed to reload custom domains", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// refresh replaces the cached domains with the stored ones. Uploaded
+// certificates that no longer parse are logged and not served.
+func (s *Service) refresh(ctx context.Context) error {
+	list, err := s.store.ListCustomDomains(ctx)
+	if err != nil {
+		return err
+	}
+	hosts := make(map[string]*entry, len(list))
+	for _, d := range list {
+		e := &entry{domain: d}
+		if d.CertSource == domain.CertSourceUploaded {
+			cert, err := tls.X509KeyPair([]byte(d.CertPEM), []byte(d.KeyPEM))
+			if err != nil {
+				slog.Warn("Skipping certificate of custom domain", "hostname", d.Hostname, "error", err)
+			} else {
+				e.cert = &cert
+			}
+		}
+		hosts[d.Hostname] = e
+	}
+	s.mu.Lock()
+	s.hosts = hosts
+	s.mu.Unlock()
+	return nil
+}
+
+// lookup returns the cached entry of a hostname, or nil
+func (s *Service) lookup(host string) *entry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.hosts[NormalizeHost(host)]
+}
+
+// Resolve returns the enabled domain a request's Host header names, or nil
+func (s *Service) Resolve(host string) *domain.CustomDomain {
+	if s == nil {
+		return nil
+	}
+	if e := s.lookup(host); e != nil && e.domain.Enabled {
+		return e.domain
+	}
+	return nil
+}
+
+// HostAllowed reports whether a request's Host header is a registered domain
+// or one of allowed_hosts
+func HostAllowed(host string, allowed []string) bool {
+	host = NormalizeHost(host)
+	return slices.ContainsFunc(allowed, func(a string) bool { return NormalizeHost(a) == host })
+}
+
+// NormalizeHost lowercases a Host header or TLS server name and strips its
+// port, IPv6 brackets and trailing dot
+func NormalizeHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.Trim(host, "[]"), ".")
+	return strings.ToLower(host)
+}
+
+// hostnamePattern matches a DNS name of at least two labels
+var hostnamePattern = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// validHostname reports whether a normalized hostname can be registered. IP
+// addresses are refused since no public CA issues certificates for them.
+func validHostname(host string) bool {
+	return len(host) <= 253 && hostnamePattern.MatchString(host) && net.ParseIP(host) == nil
+}
+
+// TerminatesTLS reports whether the listener should serve HTTPS with the
+// domains' certificates
+func (s *Service) TerminatesTLS() bool {
+	return s != nil && s.cfg.TerminateTLS
+}
+
+// TLSConfig returns base, or new listener settings when it is nil, choosing
+// the certificate by the client's server name. Names without a domain
+// certificate get base's certificates.
+func (s *Service) TLSConfig(base *tls.Config) *tls.Config {
+	tc := &tls.Config{MinVersion: tls.VersionTLS12}
+	if base != nil {
+		tc = base.Clone()
+	}
+	tc.GetCertificate = s.GetCertificate
+	if s.acme != nil {
+		tc.NextProtos = append(tc.NextProtos, "h2", "http/1.1", acme.ALPNProto)
+	}
+	return tc
+}
+
+// GetCertificate returns the certificate of the domain a TLS client asked
+// for. It returns nil for other names so the listener falls back to its
+// own certificate.
+func (s *Service) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	e := s.lookup(hello.ServerName)
+	if e == nil || !e.domain.Enabled {
+		return nil, nil
+	}
+	if e.domain.CertSource == domain.CertSourceACME {
+		if s.acme == nil {
+			return nil, nil
+		}
+		return s.acme.GetCertificate(hello)
+	}
+	return e.cert, nil
+}
+
+// acmeHostPolicy lets ACME issue certificates only for enabled domains set
+// to use it
+func (s *Service) acmeHostPolicy(ctx context.Context, host string) error {
+	if e := s.lookup(host); e != nil && e.domain.Enabled && e.domain.CertSource == domain.CertSourceACME {
+		return nil
+	}
+	return fmt.Errorf("%s is not a custom domain using ACME", host)
+}
+
+// ACMEHTTPPort returns the port to answer HTTP-01 challenges on, or 0
+func (s *Service) ACMEHTTPPort() int {
+	if s == nil || s.acme == nil {
+		return 0
+	}
+	return s.cfg.ACMEHTTPPort
+}
+
+// HTTPHandler answers ACME HTTP-01 challenges and redirects other plain HTTP
+// requests to HTTPS
+func (s *Service) HTTPHandler() http.Handler {
+	return s.acme.HTTPHandler(nil)
+}
+
+// List returns every domain, by hostname
+func (s *Service) List(ctx context.Context) ([]*domain.CustomDomain, error) {
+	return s.store.ListCustomDomains(ctx)
+}
+
+// Get returns a domain by ID
+func (s *Service) Get(ctx context.Context, id string) (*domain.CustomDomain, error) {
+	list, err := s.store.ListCustomDomains(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range list {
+		if d.ID == id {
+			return d, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// Add registers d.Hostname for d.TenantSlug (the default tenant when empty).
+// With d.CertPEM and d.KeyPEM the uploaded certificate is served, otherwise
+// one is issued through ACME on the first TLS connection.
+func (s *Service) Add(ctx context.Context, d *domain.CustomDomain) error {
+	d.Hostname = NormalizeHost(d.Hostname)
+	if !validHostname(d.Hostname) {
+		return ErrInvalidHostname
+	}
+	if d.TenantSlug == "" {
+		d.TenantSlug = "default"
+	}
+	if err := s.checkTenant(ctx, d.TenantSlug); err != nil {
+		return err
+	}
+	if err := s.setCertificate(d, d.CertPEM, d.KeyPEM); err != nil {
+		return err
+	}
+	d.Enabled = true
+
+	ok, err := s.store.CreateCustomDomain(ctx, d)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrDuplicate
+	}
+	return s.refresh(ctx)
+}
+
+// Update is a change to a custom domain. Nil and empty fields are left alone.
+type Update struct {
+	TenantSlug *string
+	Enabled    *bool
+	CertPEM    string // Replaces the certificate with this uploaded one
+	KeyPEM     string
+	UseACME    bool // Replaces the certificate with one issued through ACME
+}
+
+// Update applies u to a domain and returns it. It applies at once on this
+// instance and within refreshInterval on the others.
+func (s *Service) Update(ctx context.Context, id string, u Update) (*domain.CustomDomain, error) {
+	d, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if u.TenantSlug != nil && *u.TenantSlug != d.TenantSlug {
+		if err := s.checkTenant(ctx, *u.TenantSlug); err != nil {
+			return nil, err
+		}
+		d.TenantSlug = *u.TenantSlug
+	}
+	if u.Enabled != nil {
+		d.Enabled = *u.Enabled
+	}
+	if u.UseACME || u.CertPEM != "" || u.KeyPEM != "" {
+		if u.UseACME && (u.CertPEM != "" || u.KeyPEM != "") {
+			return nil, fmt.Errorf("%w: upload a certificate or use ACME, not both", ErrInvalidCertificate)
+		}
+		if err := s.setCertificate(d, u.CertPEM, u.KeyPEM); err != nil {
+			return nil, err
+		}
+	}
+
+	ok, err := s.store.UpdateCustomDomain(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return d, s.refresh(ctx)
+}
+
+// Delete removes a domain and returns it. Its ACME certificate stays cached
+// in case the domain is added again.
+func (s *Service) Delete(ctx context.Context, id string) (*domain.CustomDomain, error) {
+	d, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := s.store.DeleteCustomDomain(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return d, s.refresh(ctx)
+}
+
+// checkTenant returns ErrUnknownTenant unless slug names a tenant
+func (s *Service) checkTenant(ctx context.Context, slug string) error {
+	tenants, _, err := s.tenants.List(ctx, domain.TenantFilter{})
+	if err != nil {
+		return err
+	}
+	for _, t := range tenants {
+		if t.Metadata["slug"] == slug || t.ID == slug {
+			return nil
+		}
+	}
+	return ErrUnknownTenant
+}
+
+// setCertificate switches d to the uploaded certificate, or to ACME when
+// certPEM and keyPEM are empty. An uploaded certificate must match its key,
+// cover the hostname and not have expired.
+func (s *Service) setCertificate(d *domain.CustomDomain, certPEM, keyPEM string) error {
+	if certPEM == "" && keyPEM == "" {
+		if s.acme == nil {
+			return ErrACMEDisabled
+		}
+		d.CertSource, d.CertPEM, d.KeyPEM, d.CertExpiresAt = domain.CertSourceACME, "", "", nil
+		return nil
+	}
+
+	cert, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidCertificate, err)
+	}
+	leaf := cert.Leaf
+	if leaf == nil {
+		if leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidCertificate, err)
+		}
+	}
+	if err := leaf.VerifyHostname(d.Hostname); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidCertificate, err)
+	}
+	if s.now().After(leaf.NotAfter) {
+		return fmt.Errorf("%w: expired on %s", ErrInvalidCertificate, leaf.NotAfter.UTC().Format(time.DateOnly))
+	}
+	expiresAt := leaf.NotAfter
+	d.CertSource, d.CertPEM, d.KeyPEM, d.CertExpiresAt = domain.CertSourceUploaded, certPEM, keyPEM, &expiresAt
+	return nil
+}
+
+// acmeCache keeps the ACME account key and certificates in the store, so
+// every instance shares them
+type acmeCache struct {
+	store Store
+}
+
+func (c acmeCache) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := c.store.GetACMECache(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, autocert.ErrCacheMiss
+	}
+	return data, nil
+}
+
+func (c acmeCache) Put(ctx context.Context, key string, data []byte) error {
+	return c.store.PutACMECache(ctx, key, data)
+}
+
+func (c acmeCache) Delete(ctx context.Context, key string) error {
+	return c.store.DeleteACMECache(ctx, key)
+}
@@ -0,0 +1,211 @@
+package customdomains
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"strconv"
+	"testing"
+	"time"
+
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+)
+
+type fakeStore struct {
+	domains map[string]*domain.CustomDomain
+	nextID  int
+}
+
+func (f *fakeStore) ListCustomDomains(ctx context.Context) ([]*domain.CustomDomain, error) {
+	var list []*domain.CustomDomain
+	for _, d := range f.domains {
+		copied := *d
+		list = append(list, &copied)
+	}
+	return list, nil
+}
+
+func (f *fakeStore) CreateCustomDomain(ctx context.Context, d *domain.CustomDomain) (bool, error) {
+	for _, existing := range f.domains {
+		if existing.Hostname == d.Hostname {
+			return false, nil
+		}
+	}
+	f.nextID++
+	d.ID = strconv.Itoa(f.nextID)
+	copied := *d
+	f.domains[d.ID] = &copied
+	return true, nil
+}
+
+func (f *fakeStore) UpdateCustomDomain(ctx context.Context, d *domain.CustomDomain) (bool, error) {
+	if _, ok := f.domains[d.ID]; !ok {
+		return false, nil
+	}
+	copied := *d
+	f.domains[d.ID] = &copied
+	return true, nil
+}
+
+func (f *fakeStore) DeleteCustomDomain(ctx context.Context, id string) (bool, error) {
+	_, ok := f.domains[id]
+	delete(f.domains, id)
+	return ok, nil
+}
+
+func (f *fakeStore) GetACMECache(ctx context.Context, key string) ([]byte, error) { return nil, nil }
+func (f *fakeStore) PutACMECache(ctx context.Context, key string, data []byte) error {
+	return nil
+}
+func (f *fakeStore) DeleteACMECache(ctx context.Context, key string) error { return nil }
+
+type fakeTenants struct{}
+
+func (fakeTenants) List(ctx context.Context, filter domain.TenantFilter) ([]*domain.Tenant, string, error) {
+	return []*domain.Tenant{{ID: "default", Metadata: map[string]string{"slug": "default"}}}, "", nil
+}
+
+// selfSigned returns a certificate and key for hostname, valid until notAfter
+func selfSigned(t *testing.T, hostname string, notAfter time.Time) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: hostname},
+		DNSNames:     []string{hostname},
+		NotBefore:    notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return string(certPEM), string(keyPEM)
+}
+
+func TestUploadedDomain(t *testing.T) {
+	ctx := context.Background()
+	store := &fakeStore{domains: map[string]*domain.CustomDomain{}}
+	s := NewService(config.Default().Domains, store, fakeTenants{})
+
+	certPEM, keyPEM := selfSigned(t, "api.customer.com", time.Now().Add(90*24*time.Hour))
+	otherPEM, otherKey := selfSigned(t, "other.example.com", time.Now().Add(90*24*time.Hour))
+	expiredPEM, expiredKey := selfSigned(t, "api.customer.com", time.Now().Add(-time.Hour))
+
+	for name, tc := range map[string]struct {
+		d    domain.CustomDomain
+		want error
+	}{
+		"url":            {domain.CustomDomain{Hostname: "https://api.customer.com/v1", CertPEM: certPEM, KeyPEM: keyPEM}, ErrInvalidHostname},
+		"ip":             {domain.CustomDomain{Hostname: "10.0.0.1", CertPEM: certPEM, KeyPEM: keyPEM}, ErrInvalidHostname},
+		"unknown tenant": {domain.CustomDomain{Hostname: "api.customer.com", TenantSlug: "acme", CertPEM: certPEM, KeyPEM: keyPEM}, ErrUnknownTenant},
+		"no acme":        {domain.CustomDomain{Hostname: "api.customer.com"}, ErrACMEDisabled},
+		"wrong host":     {domain.CustomDomain{Hostname: "api.customer.com", CertPEM: otherPEM, KeyPEM: otherKey}, ErrInvalidCertificate},
+		"expired":        {domain.CustomDomain{Hostname: "api.customer.com", CertPEM: expiredPEM, KeyPEM: expiredKey}, ErrInvalidCertificate},
+		"key mismatch":   {domain.CustomDomain{Hostname: "api.customer.com", CertPEM: certPEM, KeyPEM: otherKey}, ErrInvalidCertificate},
+	} {
+		if err := s.Add(ctx, &tc.d); !errors.Is(err, tc.want) {
+			t.Errorf("%s: expected %v, got %v", name, tc.want, err)
+		}
+	}
+
+	d := &domain.CustomDomain{Hostname: "API.Customer.com.", CertPEM: certPEM, KeyPEM: keyPEM}
+	if err := s.Add(ctx, d); err != nil {
+		t.Fatal(err)
+	}
+	if d.Hostname != "api.customer.com" || d.TenantSlug != "default" || d.CertSource != domain.CertSourceUploaded || d.CertExpiresAt == nil {
+		t.Fatalf("unexpected domain %+v", d)
+	}
+	if err := s.Add(ctx, &domain.CustomDomain{Hostname: "api.customer.com", CertPEM: certPEM, KeyPEM: keyPEM}); !errors.Is(err, ErrDuplicate) {
+		t.Fatalf("expected ErrDuplicate, got %v", err)
+	}
+
+	if got := s.Resolve("api.customer.com:443"); got == nil || got.TenantSlug != "default" {
+		t.Fatalf("expected the domain to resolve, got %+v", got)
+	}
+	cert, err := s.GetCertificate(&tls.ClientHelloInfo{ServerName: "api.customer.com"})
+	if err != nil || cert == nil {
+		t.Fatalf("expected the uploaded certificate, got %v, %v", cert, err)
+	}
+	if cert, _ := s.GetCertificate(&tls.ClientHelloInfo{ServerName: "gateway.internal"}); cert != nil {
+		t.Fatal("expected other names to fall back to the listener certificate")
+	}
+
+	disabled := false
+	if _, err := s.Update(ctx, d.ID, Update{Enabled: &disabled}); err != nil {
+		t.Fatal(err)
+	}
+	if s.Resolve("api.customer.com") != nil {
+		t.Fatal("expected a disabled domain not to resolve")
+	}
+	if _, err := s.Update(ctx, "missing", Update{Enabled: &disabled}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	if _, err := s.Delete(ctx, d.ID); err != nil {
+		t.Fatal(err)
+	}
+	var nilService *Service
+	if nilService.Resolve("api.customer.com") != nil || nilService.TerminatesTLS() {
+		t.Fatal("expected a nil service to serve no domains")
+	}
+}
+
+func TestACMEDomain(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.Default().Domains
+	cfg.ACMEEmail = "ops@example.com"
+	s := NewService(cfg, &fakeStore{domains: map[string]*domain.CustomDomain{}}, fakeTenants{})
+
+	d := &domain.CustomDomain{Hostname: "api.customer.com"}
+	if err := s.Add(ctx, d); err != nil {
+		t.Fatal(err)
+	}
+	if d.CertSource != domain.CertSourceACME {
+		t.Fatalf("expected an ACME certificate, got %s", d.CertSource)
+	}
+	if err := s.acmeHostPolicy(ctx, "api.customer.com"); err != nil {
+		t.Fatalf("expected ACME to be allowed for the domain: %v", err)
+	}
+	if err := s.acmeHostPolicy(ctx, "evil.example.com"); err == nil {
+		t.Fatal("expected ACME to be refused for unregistered hostnames")
+	}
+
+	tc := s.TLSConfig(nil)
+	if tc.GetCertificate == nil || len(tc.NextProtos) != 3 {
+		t.Fatalf("expected TLS settings answering ACME challenges, got %v", tc.NextProtos)
+	}
+}
+
+func TestHostAllowed(t *testing.T) {
+	allowed := []string{"localhost", "Gateway.Example.com", "::1"}
+	for host, want := range map[string]bool{
+		"localhost:8080":           true,
+		"gateway.example.com":      true,
+		"GATEWAY.example.com.:443": true,
+		"[::1]:8080":               true,
+		"api.customer.com":         false,
+		"":                         false,
+	} {
+		if got := HostAllowed(host, allowed); got != want {
+			t.Errorf("HostAllowed(%q) = %v, want %v", host, got, want)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+// Package custommodels registers models served by OpenAI-compatible endpoints
+// (vLLM, TGI, LM Studio, ...) under the custom provider and keeps them in sync
+// with the gateway's available models.
+package custommodels
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"modelgate/internal/domain"
+)
+
+var (
+	// ErrModelNotFound is returned when the custom model does not exist
+	ErrModelNotFound = errors.New("custom model not found")
+	// ErrInvalidModel is returned when a custom model fails validation
+	ErrInvalidModel = errors.New("invalid custom model")
+)
+
+// Store is the persistence used by the service (implemented by postgres.TenantStore)
+type Store interface {
+	CreateCustomModel(ctx context.Context, m *domain.CustomModel) error
+	GetCustomModel(ctx context.Context, id string) (*domain.CustomModel, error)
+	ListCustomModels(ctx context.Context) ([]*domain.CustomModel, error)
+	UpdateCustomModel(ctx context.Context, m *domain.CustomModel) error
+	DeleteCustomModel(ctx context.Context, id string) (bool, error)
+
+	GetProviderConfig(ctx context.Context, provider domain.Provider) (*domain.ProviderConfig, error)
+	SaveProviderConfig(ctx context.Context, config *domain.ProviderConfig) error
+	DeleteProviderModels(ctx context.Context, provider string) error
+	SaveAvailableModels(ctx context.Context, provider string, models []domain.ModelInfo) error
+}
+
+// Service manages custom models
+type Service struct {
+	store Store
+}
+
+// NewService creates a new custom model service
+func NewService(store Store) *Service {
+	return &Service{store: store}
+}
+
+// List returns all registered custom models
+func (s *Service) List(ctx context.Context) ([]*domain.CustomModel, error) {
+	return s.store.ListCustomModels(ctx)
+}
+
+// Get returns a custom model
+func (s *Service) Get(ctx context.Context, id string) (*domain.CustomModel, error) {
+	m, err := s.store.GetCustomModel(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return nil, ErrModelNotFound
+	}
+	return m, nil
+}
+
+// Register adds a custom model. The custom provider is created on the first
+// registration, so the model can be used right away.
+func (s *Service) Register(ctx context.Context, m *domain.CustomModel) error {
+	if err := s.validate(ctx, m); err != nil {
+		return err
+	}
+	if err := s.store.CreateCustomModel(ctx, m); err != nil {
+		return fmt.Errorf("failed to create custom model: %w", err)
+	}
+	return s.Sync(ctx)
+}
+
+// Update saves changes to a custom model
+func (s *Service) Update(ctx context.Context, m *domain.CustomModel) error {
+	if _, err := s.Get(ctx, m.ID); err != nil {
+		return err
+	}
+	if err := s.validate(ctx, m); err != nil {
+		return err
+	}
+	if err := s.store.UpdateCustomModel(ctx, m); err != nil {
+		return fmt.Errorf("failed to update custom model: %w", err)
+	}
+	return s.Sync(ctx)
+}
+
+// Delete removes a custom model
+func (s *Service) Delete(ctx context.Context, id string) error {
+	deleted, err := s.store.DeleteCustomModel(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete custom model: %w", err)
+	}
+	if !deleted {
+		return ErrModelNotFound
+	}
+	return s.Sync(ctx)
+}
+
+// Sync replaces the custom entries in available_models with the enabled
+// custom models. Nothing is listed while an admin has the provider disabled.
+func (s *Service) Sync(ctx context.Context) error {
+	cfg, err := s.store.GetProviderConfig(ctx, domain.ProviderCustom)
+	if err != nil {
+		return fmt.Errorf("failed to get provider config: %w", err)
+	}
+	if cfg == nil {
+		cfg = &domain.ProviderConfig{
+			Provider:           domain.ProviderCustom,
+			Enabled:            true,
+			ConnectionSettings: domain.DefaultConnectionSettings(),
+		}
+		if err := s.store.SaveProviderConfig(ctx, cfg); err != nil {
+			return fmt.Errorf("failed to create custom provider: %w", err)
+		}
+	}
+
+	if err := s.store.DeleteProviderModels(ctx, string(domain.ProviderCustom)); err != nil {
+		return fmt.Errorf("failed to delete existing models: %w", err)
+	}
+	if !cfg.Enabled {
+		return nil
+	}
+
+	registered, err := s.store.ListCustomModels(ctx)
+	if err != nil {
+		return err
+	}
+	var models []domain.ModelInfo
+	for _, m := range registered {
+		if m.Enabled {
+			models = append(models, m.ModelInfo())
+		}
+	}
+	if len(models) == 0 {
+		return nil
+	}
+	if err := s.store.SaveAvailableModels(ctx, string(domain.ProviderCustom), models); err != nil {
+		return fmt.Errorf("failed to save models to database: %w", err)
+	}
+	return nil
+}
+
+// validate checks the model's fields and that its model ID is free
+func (s *Service) validate(ctx context.Context, m *domain.CustomModel) error {
+	m.ModelID = strings.TrimPrefix(strings.TrimSpace(m.ModelID), string(domain.ProviderCustom)+"/")
+	m.BaseURL = strings.TrimRight(strings.TrimSpace(m.BaseURL), "/")
+
+	if m.ModelID == "" {
+		return fmt.Errorf("%w: model ID is required", ErrInvalidModel)
+	}
+	if strings.ContainsAny(m.ModelID, " \t\n") {
+		return fmt.Errorf("%w: model ID %q contains whitespace", ErrInvalidModel, m.ModelID)
+	}
+	u, err := url.Parse(m.BaseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: base URL must be an http(s) URL", ErrInvalidModel)
+	}
+	if m.ContextWindow < 0 || m.MaxOutputTokens < 0 {
+		return fmt.Errorf("%w: token limits cannot be negative", ErrInvalidModel)
+	}
+	if m.InputCostPer1M < 0 || m.OutputCostPer1M < 0 {
+		return fmt.Errorf("%w: prices cannot be negative", ErrInvalidModel)
+	}
+
+	existing, err := s.store.ListCustomModels(ctx)
+	if err != nil {
+		return err
+	}
+	for _, other := range existing {
+		if other.ModelID == m.ModelID && other.ID != m.ID {
+			return fmt.Errorf("%w: model ID %q is already registered", ErrInvalidModel, m.ModelID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package custommodels
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"modelgate/internal/domain"
+)
+
+type fakeStore struct {
+	models   map[string]*domain.CustomModel
+	nextID   int
+	provider *domain.ProviderConfig
+	saved    []domain.ModelInfo
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{models: make(map[string]*domain.CustomModel)}
+}
+
+func (f *fakeStore) CreateCustomModel(ctx context.Context, m *domain.CustomModel) error {
+	f.nextID++
+	m.ID = fmt.Sprintf("cm-%d", f.nextID)
+	stored := *m
+	f.models[m.ID] = &stored
+	return nil
+}
+
+func (f *fakeStore) GetCustomModel(ctx context.Context, id string) (*domain.CustomModel, error) {
+	m, ok := f.models[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *m
+	return &copied, nil
+}
+
+func (f *fakeStore) ListCustomModels(ctx context.Context) ([]*domain.CustomModel, error) {
+	var out []*domain.CustomModel
+	for _, m := range f.models {
+		copied := *m
+		out = append(out, &copied)
+	}
+	return out, nil
+}
+
+func (f *fakeStore) UpdateCustomModel(ctx context.Context, m *domain.CustomModel) error {
+	stored := *m
+	f.models[m.ID] = &stored
+	return nil
+}
+
+func (f *fakeStore) DeleteCustomModel(ctx context.Context, id string) (bool, error) {
+	_, ok := f.models[id]
+	delete(f.models, id)
+	return ok, nil
+}
+
+func (f *fakeStore) GetProviderConfig(ctx context.Context, p domain.Provider) (*domain.ProviderConfig, error) {
+	return f.provider, nil
+}
+
+func (f *fakeStore) SaveProviderConfig(ctx context.Context, config *domain.ProviderConfig) error {
+	f.provider = config
+	return nil
+}
+
+func (f *fakeStore) DeleteProviderModels(ctx context.Context, provider string) error {
+	f.saved = nil
+	return nil
+}
+
+func (f *fakeStore) SaveAvailableModels(ctx context.Context, provider string, models []domain.ModelInfo) error {
+	f.saved = models
+	return nil
+}
+
+func TestRegisterSyncsAvailableModels(t *testing.T) {
+	store := newFakeStore()
+	svc := NewService(store)
+	ctx := context.Background()
+
+	m := &domain.CustomModel{
+		ModelID:        "custom/llama-3-70b",
+		BaseURL:        "http://vllm:8000/v1/",
+		UpstreamModel:  "meta-llama/Meta-Llama-3-70B-Instruct",
+		ContextWindow:  8192,
+		InputCostPer1M: 0.5,
+		SupportsTools:  true,
+		Enabled:        true,
+	}
+	if err := svc.Register(ctx, m); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if m.ModelID != "llama-3-70b" || m.BaseURL != "http://vllm:8000/v1" {
+		t.Fatalf("expected the model ID and base URL to be normalized, got %q and %q", m.ModelID, m.BaseURL)
+	}
+	if store.provider == nil || !store.provider.Enabled {
+		t.Fatal("expected the first registration to create an enabled custom provider")
+	}
+	if len(store.saved) != 1 || store.saved[0].ID != "custom/llama-3-70b" ||
+		store.saved[0].NativeModelID != "meta-llama/Meta-Llama-3-70B-Instruct" || !store.saved[0].SupportsTools {
+		t.Fatalf("unexpected available models: %+v", store.saved)
+	}
+
+	// Disabling the model takes it out of the available models
+	m.Enabled = false
+	if err := svc.Update(ctx, m); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if len(store.saved) != 0 {
+		t.Fatalf("expected no available models, got %+v", store.saved)
+	}
+
+	if err := svc.Delete(ctx, m.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if err := svc.Delete(ctx, m.ID); !errors.Is(err, ErrModelNotFound) {
+		t.Fatalf("expected ErrModelNotFound, got %v", err)
+	}
+}
+
+func TestRegisterValidates(t *testing.T) {
+	store := newFakeStore()
+	svc := NewService(store)
+	ctx := context.Background()
+
+	if err := svc.Register(ctx, &domain.CustomModel{ModelID: "qwen", BaseURL: "http://tgi:8080/v1"}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	invalid := []*domain.CustomModel{
+		{ModelID: "", BaseURL: "http://tgi:8080/v1"},
+		{ModelID: "my model", BaseURL: "http://tgi:8080/v1"},
+		{ModelID: "m", BaseURL: "tgi:8080"},
+		{ModelID: "m", BaseURL: "http://tgi:8080/v1", ContextWindow: -1},
+		{ModelID: "m", BaseURL: "http://tgi:8080/v1", OutputCostPer1M: -1},
+		{ModelID: "qwen", BaseURL: "http://other:8080/v1"},
+	}
+	for _, m := range invalid {
+		if err := svc.Register(ctx, m); !errors.Is(err, ErrInvalidModel) {
+			t.Errorf("expected %+v to be rejected, got %v", m, err)
+		}
+	}
+}
+
+func TestSyncRespectsDisabledProvider(t *testing.T) {
+	store := newFakeStore()
+	store.provider = &domain.ProviderConfig{Provider: domain.ProviderCustom, Enabled: false}
+	svc := NewService(store)
+
+	if err := svc.Register(context.Background(), &domain.CustomModel{ModelID: "m", BaseURL: "http://x/v1", Enabled: true}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if store.provider.Enabled || len(store.saved) != 0 {
+		t.Fatal("expected a disabled provider to stay disabled with no available models")
+	}
+}
@@ -0,0 +1,267 @@
+// Package dataaudit records a compact audit event for every data-plane (/v1)
+// request when a tenant turns it on. Events are queued in memory and written
+// in batches to the monthly partitions of data_plane_audit_events, posted to a
+// webhook, or both, so recording never slows a request down.
+package dataaudit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+)
+
+// Store is the persistence used by the service (implemented by postgres.TenantStore)
+type Store interface {
+	GetDataPlaneAuditSettings(ctx context.Context) (*domain.DataPlaneAuditSettings, error)
+	SaveDataPlaneAuditSettings(ctx context.Context, settings *domain.DataPlaneAuditSettings) error
+	InsertDataPlaneAuditEvents(ctx context.Context, events []domain.DataPlaneAuditEvent) error
+	ListDataPlaneAuditEvents(ctx context.Context, filter domain.DataPlaneAuditFilter) ([]domain.DataPlaneAuditEvent, error)
+	EnsureDataPlaneAuditPartition(ctx context.Context, t time.Time) error
+	DropDataPlaneAuditPartitions(ctx context.Context, cutoff time.Time) ([]string, error)
+}
+
+const (
+	// refreshInterval bounds how long a settings change made on another
+	// instance takes to apply here
+	refreshInterval = 10 * time.Second
+	// maintenanceInterval is how often partitions are created ahead and expired
+	maintenanceInterval = 6 * time.Hour
+)
+
+// Service queues and delivers data-plane audit events
+type Service struct {
+	cfg        config.DataPlaneAuditConfig
+	store      Store
+	httpClient *http.Client
+	now        func() time.Time
+
+	settings atomic.Pointer[domain.DataPlaneAuditSettings]
+	events   chan domain.DataPlaneAuditEvent
+	dropped  atomic.Int64
+}
+
+// NewService creates a data-plane audit service. It records nothing until
+// settings enabling it are loaded or saved.
+func NewService(cfg config.DataPlaneAuditConfig, store Store) *Service {
+	s := &Service{
+		cfg:        cfg,
+		store:      store,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		now:        time.Now,
+		events:     make(chan domain.DataPlaneAuditEvent, cfg.BufferSize),
+	}
+	s.settings.Store(&domain.DataPlaneAuditSettings{})
+	return s
+}
+
+// Enabled reports whether events should be recorded
+func (s *Service) Enabled() bool {
+	if s == nil {
+		return false
+	}
+	settings := s.settings.Load()
+	return settings.Enabled && (settings.StoreEvents || settings.WebhookURL != "")
+}
+
+// Record queues an event. It never blocks: when the buffer is full the event
+// is dropped and counted.
+func (s *Service) Record(event domain.DataPlaneAuditEvent) {
+	if !s.Enabled() {
+		return
+	}
+	select {
+	case s.events <- event:
+	default:
+		if s.dropped.Add(1)%1000 == 1 {
+			slog.Warn("Data-plane audit buffer full, dropping events", "dropped", s.dropped.Load())
+		}
+	}
+}
+
+// Dropped returns how many events were dropped because the buffer was full
+func (s *Service) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+// Settings returns the settings in effect
+func (s *Service) Settings() domain.DataPlaneAuditSettings {
+	return *s.settings.Load()
+}
+
+// Update saves new settings and applies them on this instance at once
+func (s *Service) Update(ctx context.Context, settings domain.DataPlaneAuditSettings, updatedBy string) (*domain.DataPlaneAuditSettings, error) {
+	if settings.WebhookURL != "" {
+		u, err := url.Parse(settings.WebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("webhookUrl must be an http or https URL")
+		}
+	}
+	if settings.Enabled && !settings.StoreEvents && settings.WebhookURL == "" {
+		return nil, fmt.Errorf("an enabled data-plane audit needs storeEvents or a webhookUrl")
+	}
+	settings.UpdatedBy = updatedBy
+	settings.UpdatedAt = s.now()
+	if settings.Enabled && settings.StoreEvents {
+		// Events must have a partition to go to before the first one is written
+		if err := s.ensurePartitions(ctx); err != nil {
+			return nil, fmt.Errorf("create audit event partitions: %w", err)
+		}
+	}
+	if err := s.store.SaveDataPlaneAuditSettings(ctx, &settings); err != nil {
+		return nil, fmt.Errorf("save data-plane audit settings: %w", err)
+	}
+	s.settings.Store(&settings)
+	return &settings, nil
+}
+
+// List returns stored events, newest first
+func (s *Service) List(ctx context.Context, filter domain.DataPlaneAuditFilter) ([]domain.DataPlaneAuditEvent, error) {
+	return s.store.ListDataPlaneAuditEvents(ctx, filter)
+}
+
+// Start loads the settings, then delivers events and maintains partitions
+// until ctx is done. Events still queued then are flushed.
+func (s *Service) Start(ctx context.Context) {
+	s.refresh(ctx)
+	s.maintain(ctx)
+
+	go func() {
+		flush := time.NewTicker(s.cfg.FlushInterval)
+		defer flush.Stop()
+		refresh := time.NewTicker(refreshInterval)
+		defer refresh.Stop()
+		maintenance := time.NewTicker(maintenanceInterval)
+		defer maintenance.Stop()
+
+		batch := make([]domain.DataPlaneAuditEvent, 0, s.cfg.BatchSize)
+		for {
+			select {
+			case <-ctx.Done():
+				for {
+					select {
+					case event := <-s.events:
+						batch = append(batch, event)
+					default:
+						s.deliver(context.WithoutCancel(ctx), batch)
+						return
+					}
+				}
+			case event := <-s.events:
+				batch = append(batch, event)
+				if len(batch) >= s.cfg.BatchSize {
+					s.deliver(ctx, batch)
+					batch = batch[:0]
+				}
+			case <-flush.C:
+				if len(batch) > 0 {
+					s.deliver(ctx, batch)
+					batch = batch[:0]
+				}
+			case <-refresh.C:
+				s.refresh(ctx)
+			case <-maintenance.C:
+				s.maintain(ctx)
+			}
+		}
+	}()
+}
+
+// refresh reloads the settings, which another instance may have changed
+func (s *Service) refresh(ctx context.Context) {
+	settings, err := s.store.GetDataPlaneAuditSettings(ctx)
+	if err != nil {
+		slog.Warn("Failed to load data-plane audit settings", "error", err)
+		return
+	}
+	if settings == nil {
+		settings = &domain.DataPlaneAuditSettings{}
+	}
+	s.settings.Store(settings)
+}
+
+// maintain creates the partitions of this month and the next, and drops the
+// months past retention
+func (s *Service) maintain(ctx context.Context) {
+	if settings := s.settings.Load(); settings.Enabled && settings.StoreEvents {
+		if err := s.ensurePartitions(ctx); err != nil {
+			slog.Warn("Failed to create data-plane audit partitions", "error", err)
+		}
+	}
+	if s.cfg.RetentionMonths <= 0 {
+		return
+	}
+	now := s.now().UTC()
+	cutoff := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -s.cfg.RetentionMonths, 0)
+	dropped, err := s.store.DropDataPlaneAuditPartitions(ctx, cutoff)
+	if err != nil {
+		slog.Warn("Failed to drop expired data-plane audit partitions", "error", err)
+	}
+	if len(dropped) > 0 {
+		slog.Info("Dropped expired data-plane audit partitions", "partitions", dropped)
+	}
+}
+
+func (s *Service) ensurePartitions(ctx context.Context) error {
+	now := s.now().UTC()
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for _, month := range []time.Time{month, month.AddDate(0, 1, 0)} {
+		if err := s.store.EnsureDataPlaneAuditPartition(ctx, month); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deliver writes a batch to the sinks the settings select. Failures are
+// logged; the batch is not retried.
+func (s *Service) deliver(ctx context.Context, batch []domain.DataPlaneAuditEvent) {
+	if len(batch) == 0 {
+		return
+	}
+	settings := s.settings.Load()
+	if settings.StoreEvents {
+		if err := s.store.InsertDataPlaneAuditEvents(ctx, batch); err != nil {
+			slog.Error("Failed to write data-plane audit events", "events", len(batch), "error", err)
+		}
+	}
+	if settings.WebhookURL != "" {
+		s.postWebhook(ctx, settings.WebhookURL, batch)
+	}
+}
+
+// webhookPayload is the body posted to the webhook
+type webhookPayload struct {
+	Event  string                       `json:"event"`
+	Events []domain.DataPlaneAuditEvent `json:"events"`
+}
+
+func (s *Service) postWebhook(ctx context.Context, webhookURL string, batch []domain.DataPlaneAuditEvent) {
+	body, err := json.Marshal(webhookPayload{Event: "data_plane_audit.events", Events: batch})
+	if err != nil {
+		return
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		slog.Error("Failed to build data-plane audit webhook", "error", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		slog.Error("Failed to send data-plane audit webhook", "events", len(batch), "error", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		slog.Error("Data-plane audit webhook failed", "events", len(batch), "status", resp.Status)
+	}
+}
@@ -0,0 +1,121 @@
+package dataaudit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+)
+
+type memStore struct {
+	mu         sync.Mutex
+	settings   *domain.DataPlaneAuditSettings
+	events     []domain.DataPlaneAuditEvent
+	partitions []time.Time
+	cutoff     time.Time
+}
+
+func (m *memStore) GetDataPlaneAuditSettings(ctx context.Context) (*domain.DataPlaneAuditSettings, error) {
+	return m.settings, nil
+}
+
+func (m *memStore) SaveDataPlaneAuditSettings(ctx context.Context, settings *domain.DataPlaneAuditSettings) error {
+	m.settings = settings
+	return nil
+}
+
+func (m *memStore) InsertDataPlaneAuditEvents(ctx context.Context, events []domain.DataPlaneAuditEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events = append(m.events, events...)
+	return nil
+}
+
+func (m *memStore) ListDataPlaneAuditEvents(ctx context.Context, filter domain.DataPlaneAuditFilter) ([]domain.DataPlaneAuditEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.events, nil
+}
+
+func (m *memStore) EnsureDataPlaneAuditPartition(ctx context.Context, t time.Time) error {
+	m.partitions = append(m.partitions, t)
+	return nil
+}
+
+func (m *memStore) DropDataPlaneAuditPartitions(ctx context.Context, cutoff time.Time) ([]string, error) {
+	m.cutoff = cutoff
+	return nil, nil
+}
+
+func TestDataPlaneAudit(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	var posted []domain.DataPlaneAuditEvent
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload webhookPayload
+		json.NewDecoder(r.Body).Decode(&payload)
+		mu.Lock()
+		posted = append(posted, payload.Events...)
+		mu.Unlock()
+	}))
+	defer webhook.Close()
+
+	store := &memStore{}
+	svc := NewService(config.DataPlaneAuditConfig{BufferSize: 2, BatchSize: 10, FlushInterval: 10 * time.Millisecond, RetentionMonths: 3}, store)
+	svc.now = func() time.Time { return time.Date(2026, 12, 18, 12, 0, 0, 0, time.UTC) }
+	svc.Start(ctx)
+
+	// Nothing is recorded until a tenant turns it on
+	svc.Record(domain.DataPlaneAuditEvent{Path: "/v1/models"})
+	if svc.Enabled() || len(svc.events) != 0 {
+		t.Fatal("expected events to be ignored while disabled")
+	}
+	want := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	if !store.cutoff.Equal(want) {
+		t.Fatalf("expected partitions before %v to expire, got %v", want, store.cutoff)
+	}
+
+	if _, err := svc.Update(ctx, domain.DataPlaneAuditSettings{Enabled: true}, "admin@example.com"); err == nil {
+		t.Fatal("expected an enabled audit without a sink to be refused")
+	}
+	if _, err := svc.Update(ctx, domain.DataPlaneAuditSettings{Enabled: true, WebhookURL: "ftp://example.com"}, "admin@example.com"); err == nil {
+		t.Fatal("expected a non-HTTP webhook to be refused")
+	}
+	saved, err := svc.Update(ctx, domain.DataPlaneAuditSettings{Enabled: true, StoreEvents: true, WebhookURL: webhook.URL}, "admin@example.com")
+	if err != nil || saved.UpdatedBy != "admin@example.com" {
+		t.Fatalf("unexpected update result %+v, %v", saved, err)
+	}
+	// This month's and next month's partitions, across the year boundary
+	if len(store.partitions) != 2 || !store.partitions[1].Equal(time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected partitions for December and January, got %v", store.partitions)
+	}
+
+	svc.Record(domain.DataPlaneAuditEvent{Path: "/v1/chat/completions", Model: "gpt-4o", PolicyDecision: "allowed"})
+	svc.Record(domain.DataPlaneAuditEvent{Path: "/v1/embeddings"})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		mu.Lock()
+		n := len(posted)
+		mu.Unlock()
+		events, _ := svc.List(ctx, domain.DataPlaneAuditFilter{})
+		if n == 2 && len(events) == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected both events stored and posted, got %d stored and %d posted", len(events), n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if posted[0].Model != "gpt-4o" || posted[0].PolicyDecision != "allowed" {
+		t.Fatalf("unexpected webhook event %+v", posted[0])
+	}
+}
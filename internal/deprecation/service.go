@@ -0,0 +1,286 @@
+// Package deprecation tracks models their providers are retiring. Admins
+// record a model's sunset date and replacement on its catalog entry; requests
+// for the model are then answered with an X-ModelGate-Deprecation header,
+// moved to the replacement for roles that ask for it, and refused once the
+// model is gone. Admins are alerted ahead of each sunset.
+package deprecation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+	"modelgate/internal/email"
+)
+
+// HeaderName is the response header warning clients about a deprecated model
+const HeaderName = "X-ModelGate-Deprecation"
+
+// refreshInterval bounds how long a deprecation set on another instance takes
+// to apply here
+const refreshInterval = time.Minute
+
+var (
+	ErrNotFound       = errors.New("model not found in the catalog")
+	ErrSunsetRequired = errors.New("a sunset date is required")
+	ErrSelfReplace    = errors.New("a model can't be its own replacement")
+)
+
+// Store is the persistence used by the service (implemented by postgres.TenantStore)
+type Store interface {
+	ListModelDeprecations(ctx context.Context) ([]*domain.ModelDeprecation, error)
+	SetModelDeprecation(ctx context.Context, d *domain.ModelDeprecation) (bool, error)
+	ClearModelDeprecation(ctx context.Context, provider domain.Provider, modelID string) (bool, error)
+	MarkSunsetAlerted(ctx context.Context, provider domain.Provider, modelID string, at time.Time) error
+	ListAdminEmails(ctx context.Context) ([]string, error)
+}
+
+// Service answers deprecation lookups from a cached copy of the stored
+// deprecations and sends sunset alerts
+type Service struct {
+	cfg        config.ModelDeprecationConfig
+	store      Store
+	sender     email.Sender // nil when email is disabled
+	httpClient *http.Client
+	now        func() time.Time
+
+	mu     sync.RWMutex
+	models map[string]*domain.ModelDeprecation // Model ID, with and without provider prefix -> deprecation
+}
+
+// NewService creates a deprecation service. Without a sender, sunset alerts
+// go only to the configured webhook.
+func NewService(cfg config.ModelDeprecationConfig, store Store, sender email.Sender) *Service {
+	return &Service{
+		cfg:        cfg,
+		store:      store,
+		sender:     sender,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		now:        time.Now,
+		models:     make(map[string]*domain.ModelDeprecation),
+	}
+}
+
+// Start loads the deprecations, then reloads them every refreshInterval and
+// checks for upcoming sunsets every check_interval, until ctx is cancelled
+func (s *Service) Start(ctx context.Context) {
+	if err := s.refresh(ctx); err != nil {
+		slog.Warn("Failed to load model deprecations", "error", err)
+	}
+	go func() {
+		refresh := time.NewTicker(refreshInterval)
+		defer refresh.Stop()
+		check := time.NewTicker(s.cfg.CheckInterval)
+		defer check.Stop()
+		s.checkAlerts(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-refresh.C:
+				if err := s.refresh(ctx); err != nil {
+					slog.Warn("Failed to reload model deprecations", "error", err)
+				}
+			case <-check.C:
+				s.checkAlerts(ctx)
+			}
+		}
+	}()
+}
+
+// refresh replaces the cached deprecations with the stored ones
+func (s *Service) refresh(ctx context.Context) error {
+	list, err := s.store.ListModelDeprecations(ctx)
+	if err != nil {
+		return err
+	}
+	models := make(map[string]*domain.ModelDeprecation, 2*len(list))
+	for _, d := range list {
+		models[d.ModelID] = d
+		if prefix := string(d.Provider) + "/"; !strings.HasPrefix(d.ModelID, prefix) {
+			models[prefix+d.ModelID] = d
+		}
+	}
+	s.mu.Lock()
+	s.models = models
+	s.mu.Unlock()
+	return nil
+}
+
+// Lookup returns the deprecation of a model as clients request it, with or
+// without its provider prefix, or nil
+func (s *Service) Lookup(model string) *domain.ModelDeprecation {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.models[model]
+}
+
+// Find returns the deprecation of a provider's catalog model, or nil
+func (s *Service) Find(provider domain.Provider, modelID string) *domain.ModelDeprecation {
+	if prefix := string(provider) + "/"; !strings.HasPrefix(modelID, prefix) {
+		modelID = prefix + modelID
+	}
+	return s.Lookup(modelID)
+}
+
+// List returns every deprecation, soonest sunset first
+func (s *Service) List(ctx context.Context) ([]*domain.ModelDeprecation, error) {
+	return s.store.ListModelDeprecations(ctx)
+}
+
+// Set records a model's sunset date and replacement. It applies at once on
+// this instance and within refreshInterval on the others.
+func (s *Service) Set(ctx context.Context, d *domain.ModelDeprecation) error {
+	if d.SunsetAt.IsZero() {
+		return ErrSunsetRequired
+	}
+	if d.ReplacementModel != "" && (d.ReplacementModel == d.ModelID || d.ReplacementModel == string(d.Provider)+"/"+d.ModelID) {
+		return ErrSelfReplace
+	}
+	ok, err := s.store.SetModelDeprecation(ctx, d)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotFound
+	}
+	return s.refresh(ctx)
+}
+
+// Clear removes a model's deprecation. Returns false if it had none.
+func (s *Service) Clear(ctx context.Context, provider domain.Provider, modelID string) (bool, error) {
+	ok, err := s.store.ClearModelDeprecation(ctx, provider, modelID)
+	if err != nil || !ok {
+		return ok, err
+	}
+	return true, s.refresh(ctx)
+}
+
+// Header formats the X-ModelGate-Deprecation value for a request for model.
+// rewrittenTo is the model the request was moved to, if any.
+func Header(d *domain.ModelDeprecation, model, rewrittenTo string) string {
+	parts := []string{
+		fmt.Sprintf("model=%q", model),
+		fmt.Sprintf("sunset=%q", d.SunsetAt.UTC().Format(time.RFC3339)),
+	}
+	if d.ReplacementModel != "" {
+		parts = append(parts, fmt.Sprintf("replacement=%q", d.ReplacementModel))
+	}
+	if rewrittenTo != "" {
+		parts = append(parts, fmt.Sprintf("rewritten_to=%q", rewrittenTo))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// checkAlerts alerts admins about every sunset within alert_before they
+// haven't been alerted about yet. Alerts are recorded per sunset date, so
+// moving a sunset alerts again.
+func (s *Service) checkAlerts(ctx context.Context) {
+	if s.cfg.AlertBefore <= 0 {
+		return
+	}
+	list, err := s.store.ListModelDeprecations(ctx)
+	if err != nil {
+		slog.Error("Failed to check model sunsets", "error", err)
+		return
+	}
+	now := s.now()
+	for _, d := range list {
+		if d.AlertedAt != nil || d.Sunset(now) || d.SunsetAt.Sub(now) > s.cfg.AlertBefore {
+			continue
+		}
+		s.notifyAdmins(ctx, d, now)
+		s.postWebhook(ctx, d)
+		if err := s.store.MarkSunsetAlerted(ctx, d.Provider, d.ModelID, now); err != nil {
+			slog.Error("Failed to record model sunset alert", "provider", d.Provider, "model", d.ModelID, "error", err)
+		}
+	}
+}
+
+// alertSummary describes an upcoming sunset for the alert email
+func alertSummary(d *domain.ModelDeprecation, now time.Time) string {
+	days := int(d.SunsetAt.Sub(now).Hours() / 24)
+	summary := fmt.Sprintf("%s model %s is retired on %s (in %d days).",
+		d.Provider, d.ModelID, d.SunsetAt.UTC().Format(time.DateOnly), days)
+	if d.ReplacementModel != "" {
+		summary += fmt.Sprintf(" Its replacement is %s; roles with rewrite_deprecated_models are moved to it automatically.", d.ReplacementModel)
+	} else {
+		summary += " No replacement is set; requests for it will fail after the sunset."
+	}
+	if d.Notice != "" {
+		summary += "\n\n" + d.Notice
+	}
+	return summary
+}
+
+// notifyAdmins emails the configured addresses, or every admin, about a sunset
+func (s *Service) notifyAdmins(ctx context.Context, d *domain.ModelDeprecation, now time.Time) {
+	if s.sender == nil {
+		return
+	}
+	to := s.cfg.NotifyEmails
+	if len(to) == 0 {
+		admins, err := s.store.ListAdminEmails(ctx)
+		if err != nil {
+			slog.Error("Failed to list admins for model sunset alert", "error", err)
+			return
+		}
+		to = admins
+	}
+	if len(to) == 0 {
+		return
+	}
+	msg := &email.Message{
+		To:      to,
+		Subject: fmt.Sprintf("Model %s is retired on %s", d.ModelID, d.SunsetAt.UTC().Format(time.DateOnly)),
+		Text:    alertSummary(d, now),
+	}
+	if err := s.sender.Send(ctx, msg); err != nil {
+		slog.Error("Failed to send model sunset email", "subject", msg.Subject, "error", err)
+	}
+}
+
+// sunsetEvent is the payload posted to the notification webhook
+type sunsetEvent struct {
+	Event       string                   `json:"event"` // Always "model.sunset_upcoming"
+	Deprecation *domain.ModelDeprecation `json:"deprecation"`
+}
+
+// postWebhook posts an upcoming sunset to the notification webhook. Failures
+// are logged; the event is not retried.
+func (s *Service) postWebhook(ctx context.Context, d *domain.ModelDeprecation) {
+	if s.cfg.NotifyWebhook == "" {
+		return
+	}
+	body, err := json.Marshal(sunsetEvent{Event: "model.sunset_upcoming", Deprecation: d})
+	if err != nil {
+		return
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.NotifyWebhook, bytes.NewReader(body))
+	if err != nil {
+		slog.Error("Failed to build model sunset webhook", "error", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		slog.Error("Failed to send model sunset webhook", "model", d.ModelID, "error", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		slog.Error("Model sunset webhook failed", "model", d.ModelID, "status", resp.Status)
+	}
+}
@@ -0,0 +1,157 @@
+package deprecation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+)
+
+type fakeStore struct {
+	catalog      map[string]bool // provider/model ID -> in the catalog
+	deprecations map[string]*domain.ModelDeprecation
+	alerted      int
+}
+
+func newFakeStore(models ...string) *fakeStore {
+	f := &fakeStore{catalog: map[string]bool{}, deprecations: map[string]*domain.ModelDeprecation{}}
+	for _, m := range models {
+		f.catalog[m] = true
+	}
+	return f
+}
+
+func (f *fakeStore) ListModelDeprecations(ctx context.Context) ([]*domain.ModelDeprecation, error) {
+	var list []*domain.ModelDeprecation
+	for _, d := range f.deprecations {
+		copied := *d
+		list = append(list, &copied)
+	}
+	return list, nil
+}
+
+func (f *fakeStore) SetModelDeprecation(ctx context.Context, d *domain.ModelDeprecation) (bool, error) {
+	key := string(d.Provider) + "/" + d.ModelID
+	if !f.catalog[key] {
+		return false, nil
+	}
+	copied := *d
+	f.deprecations[key] = &copied
+	return true, nil
+}
+
+func (f *fakeStore) ClearModelDeprecation(ctx context.Context, provider domain.Provider, modelID string) (bool, error) {
+	key := string(provider) + "/" + modelID
+	if _, ok := f.deprecations[key]; !ok {
+		return false, nil
+	}
+	delete(f.deprecations, key)
+	return true, nil
+}
+
+func (f *fakeStore) MarkSunsetAlerted(ctx context.Context, provider domain.Provider, modelID string, at time.Time) error {
+	f.alerted++
+	f.deprecations[string(provider)+"/"+modelID].AlertedAt = &at
+	return nil
+}
+
+func (f *fakeStore) ListAdminEmails(ctx context.Context) ([]string, error) {
+	return nil, nil
+}
+
+func TestSetAndLookup(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore("openai/gpt-4-0613")
+	s := NewService(config.Default().Deprecation, store, nil)
+
+	sunset := time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC)
+	if err := s.Set(ctx, &domain.ModelDeprecation{Provider: domain.ProviderOpenAI, ModelID: "gpt-4-0613"}); !errors.Is(err, ErrSunsetRequired) {
+		t.Fatalf("expected ErrSunsetRequired, got %v", err)
+	}
+	if err := s.Set(ctx, &domain.ModelDeprecation{Provider: domain.ProviderOpenAI, ModelID: "gpt-4-0613", SunsetAt: sunset,
+		ReplacementModel: "openai/gpt-4-0613"}); !errors.Is(err, ErrSelfReplace) {
+		t.Fatalf("expected ErrSelfReplace, got %v", err)
+	}
+	if err := s.Set(ctx, &domain.ModelDeprecation{Provider: domain.ProviderOpenAI, ModelID: "gpt-5", SunsetAt: sunset}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	if err := s.Set(ctx, &domain.ModelDeprecation{Provider: domain.ProviderOpenAI, ModelID: "gpt-4-0613", SunsetAt: sunset,
+		ReplacementModel: "gpt-4o"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, model := range []string{"gpt-4-0613", "openai/gpt-4-0613"} {
+		if d := s.Lookup(model); d == nil || d.ReplacementModel != "gpt-4o" {
+			t.Fatalf("expected %s to be deprecated, got %+v", model, d)
+		}
+	}
+	if d := s.Find(domain.ProviderOpenAI, "gpt-4-0613"); d == nil {
+		t.Fatal("expected Find to return the deprecation")
+	}
+
+	got := Header(s.Lookup("gpt-4-0613"), "gpt-4-0613", "gpt-4o")
+	want := `model="gpt-4-0613"; sunset="2026-12-01T00:00:00Z"; replacement="gpt-4o"; rewritten_to="gpt-4o"`
+	if got != want {
+		t.Fatalf("unexpected header:\n got %s\nwant %s", got, want)
+	}
+
+	if ok, err := s.Clear(ctx, domain.ProviderOpenAI, "gpt-4-0613"); err != nil || !ok {
+		t.Fatalf("expected the deprecation to be cleared, got %v, %v", ok, err)
+	}
+	if d := s.Lookup("gpt-4-0613"); d != nil {
+		t.Fatalf("expected no deprecation after clearing, got %+v", d)
+	}
+	if ok, _ := s.Clear(ctx, domain.ProviderOpenAI, "gpt-4-0613"); ok {
+		t.Fatal("expected clearing twice to report no deprecation")
+	}
+
+	var nilService *Service
+	if nilService.Lookup("gpt-4-0613") != nil {
+		t.Fatal("expected a nil service to find nothing")
+	}
+}
+
+func TestCheckAlerts(t *testing.T) {
+	var posted atomic.Int32
+	var event sunsetEvent
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posted.Add(1)
+		_ = json.NewDecoder(r.Body).Decode(&event)
+	}))
+	defer srv.Close()
+
+	now := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	store := newFakeStore()
+	store.deprecations = map[string]*domain.ModelDeprecation{
+		"openai/soon":   {Provider: domain.ProviderOpenAI, ModelID: "soon", SunsetAt: now.Add(10 * 24 * time.Hour)},
+		"openai/later":  {Provider: domain.ProviderOpenAI, ModelID: "later", SunsetAt: now.Add(90 * 24 * time.Hour)},
+		"openai/gone":   {Provider: domain.ProviderOpenAI, ModelID: "gone", SunsetAt: now.Add(-time.Hour)},
+		"openai/warned": {Provider: domain.ProviderOpenAI, ModelID: "warned", SunsetAt: now.Add(time.Hour), AlertedAt: &now},
+	}
+
+	cfg := config.Default().Deprecation
+	cfg.NotifyWebhook = srv.URL
+	s := NewService(cfg, store, nil)
+	s.now = func() time.Time { return now }
+
+	s.checkAlerts(context.Background())
+	if got := posted.Load(); got != 1 {
+		t.Fatalf("expected 1 sunset alert, got %d", got)
+	}
+	if event.Event != "model.sunset_upcoming" || event.Deprecation == nil || event.Deprecation.ModelID != "soon" {
+		t.Fatalf("unexpected webhook event %+v", event)
+	}
+
+	// Recorded alerts aren't repeated
+	s.checkAlerts(context.Background())
+	if got := posted.Load(); got != 1 || store.alerted != 1 {
+		t.Fatalf("expected the alert to be sent once, got %d posts and %d marks", got, store.alerted)
+	}
+}
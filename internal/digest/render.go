@@ -0,0 +1,120 @@
+package digest
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"text/template"
+
+	"modelgate/internal/domain"
+	"modelgate/internal/email"
+)
+
+// view is what the digest templates render
+type view struct {
+	*domain.UsageDigest
+	Title        string
+	Scope        string
+	Period       string
+	ErrorRate    string
+	BudgetUsed   string // Share of the monthly limit spent, empty without a limit
+	OverBudget   bool   // Projected to exceed the monthly limit
+	DashboardURL string
+}
+
+func usd(v float64) string {
+	return fmt.Sprintf("$%.2f", v)
+}
+
+var funcs = template.FuncMap{"usd": usd}
+
+var textTemplate = template.Must(template.New("digest").Funcs(funcs).Parse(`{{.Title}}
+{{.Scope}}, {{.Period}}
+
+Spend:       {{usd .TotalCostUSD}}
+Requests:    {{.TotalRequests}}
+Tokens:      {{.TotalTokens}}
+Error rate:  {{.ErrorRate}}
+
+Top models
+{{range .TopModels}}  {{.ModelID}}: {{usd .CostUSD}} ({{.Requests}} requests)
+{{else}}  No requests
+{{end}}
+Top API keys
+{{range .TopAPIKeys}}  {{if .APIKeyName}}{{.APIKeyName}}{{else}}{{.APIKeyID}}{{end}}: {{usd .CostUSD}} ({{.Requests}} requests)
+{{else}}  No requests
+{{end}}
+Budget this month
+  Spent:      {{usd .Budget.SpentUSD}}{{if .BudgetUsed}} of {{usd .Budget.LimitUSD}} ({{.BudgetUsed}}){{end}}
+  Burn rate:  {{usd .Budget.DailyBurnUSD}}/day
+  Projected:  {{usd .Budget.ProjectedUSD}} by month end{{if .OverBudget}} - over the monthly limit{{end}}
+{{if .DashboardURL}}
+Dashboard: {{.DashboardURL}}
+{{end}}`))
+
+var htmlTemplate = htmltemplate.Must(htmltemplate.New("digest").Funcs(htmltemplate.FuncMap(funcs)).Parse(`<!DOCTYPE html>
+<html><body style="font-family: sans-serif; color: #222;">
+<h2>{{.Title}}</h2>
+<p>{{.Scope}}, {{.Period}}</p>
+<table cellpadding="4">
+<tr><td>Spend</td><td><b>{{usd .TotalCostUSD}}</b></td></tr>
+<tr><td>Requests</td><td>{{.TotalRequests}}</td></tr>
+<tr><td>Tokens</td><td>{{.TotalTokens}}</td></tr>
+<tr><td>Error rate</td><td>{{.ErrorRate}}</td></tr>
+</table>
+<h3>Top models</h3>
+<table cellpadding="4">
+{{range .TopModels}}<tr><td>{{.ModelID}}</td><td>{{usd .CostUSD}}</td><td>{{.Requests}} requests</td></tr>
+{{else}}<tr><td>No requests</td></tr>
+{{end}}</table>
+<h3>Top API keys</h3>
+<table cellpadding="4">
+{{range .TopAPIKeys}}<tr><td>{{if .APIKeyName}}{{.APIKeyName}}{{else}}{{.APIKeyID}}{{end}}</td><td>{{usd .CostUSD}}</td><td>{{.Requests}} requests</td></tr>
+{{else}}<tr><td>No requests</td></tr>
+{{end}}</table>
+<h3>Budget this month</h3>
+<table cellpadding="4">
+<tr><td>Spent</td><td>{{usd .Budget.SpentUSD}}{{if .BudgetUsed}} of {{usd .Budget.LimitUSD}} ({{.BudgetUsed}}){{end}}</td></tr>
+<tr><td>Burn rate</td><td>{{usd .Budget.DailyBurnUSD}}/day</td></tr>
+<tr><td>Projected</td><td{{if .OverBudget}} style="color: #c00;"{{end}}>{{usd .Budget.ProjectedUSD}} by month end{{if .OverBudget}} (over the monthly limit){{end}}</td></tr>
+</table>
+{{if .DashboardURL}}<p><a href="{{.DashboardURL}}">Open the dashboard</a></p>{{end}}
+</body></html>
+`))
+
+// render builds the digest email
+func render(d *domain.UsageDigest, dashboardURL string) (*email.Message, error) {
+	v := view{
+		UsageDigest:  d,
+		Title:        "ModelGate " + string(d.Frequency) + " usage digest",
+		Scope:        "All API keys",
+		ErrorRate:    fmt.Sprintf("%.1f%%", d.ErrorRate()*100),
+		DashboardURL: dashboardURL,
+	}
+	if d.RoleName != "" {
+		v.Scope = "API keys of role " + d.RoleName
+	}
+	if d.Frequency == domain.DigestWeekly {
+		v.Period = fmt.Sprintf("%s to %s (UTC)", d.PeriodStart.Format("Jan 2"), d.PeriodEnd.Add(-1).Format("Jan 2, 2006"))
+	} else {
+		v.Period = d.PeriodStart.Format("Monday, Jan 2, 2006") + " (UTC)"
+	}
+	if d.Budget.LimitUSD > 0 {
+		v.BudgetUsed = fmt.Sprintf("%.0f%%", d.Budget.SpentUSD/d.Budget.LimitUSD*100)
+		v.OverBudget = d.Budget.ProjectedUSD > d.Budget.LimitUSD
+	}
+
+	var text, html bytes.Buffer
+	if err := textTemplate.Execute(&text, v); err != nil {
+		return nil, fmt.Errorf("render digest: %w", err)
+	}
+	if err := htmlTemplate.Execute(&html, v); err != nil {
+		return nil, fmt.Errorf("render digest: %w", err)
+	}
+
+	subject := fmt.Sprintf("%s: %s spent, %d requests", v.Title, usd(d.TotalCostUSD), d.TotalRequests)
+	if v.OverBudget {
+		subject += " (projected over budget)"
+	}
+	return &email.Message{Subject: subject, Text: text.String(), HTML: html.String()}, nil
+}
@@ -0,0 +1,287 @@
+// Package digest sends scheduled usage digest emails. Each subscribed user
+// gets a daily or weekly summary of spend, top models, top API keys, error
+// rate and budget burn, for the whole tenant or for one role's API keys.
+package digest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+	"modelgate/internal/email"
+)
+
+// topN is how many models and API keys a digest lists
+const topN = 5
+
+var (
+	// ErrUserNotFound is returned when the user does not exist
+	ErrUserNotFound = errors.New("user not found")
+	// ErrRoleNotFound is returned when a subscription names an unknown role
+	ErrRoleNotFound = errors.New("role not found")
+	// ErrInvalidFrequency is returned for an unknown digest frequency
+	ErrInvalidFrequency = errors.New("invalid digest frequency")
+	// ErrEmailDisabled is returned when a digest is sent without email configured
+	ErrEmailDisabled = errors.New("email is not configured")
+)
+
+// Store is the persistence used by the service (implemented by postgres.TenantStore)
+type Store interface {
+	ListDigestSubscriptions(ctx context.Context) ([]*domain.DigestSubscription, error)
+	GetDigestSubscription(ctx context.Context, userID string) (*domain.DigestSubscription, error)
+	SetDigestSubscription(ctx context.Context, userID string, frequency domain.DigestFrequency, roleID string, lastSentAt *time.Time) error
+	ClaimDigest(ctx context.Context, userID string, scheduled time.Time) (bool, error)
+	ReleaseDigest(ctx context.Context, userID string, previous *time.Time) error
+	GetDigestUsage(ctx context.Context, start, end time.Time, roleID string, top int) (*domain.UsageDigest, error)
+	GetRole(ctx context.Context, id string) (*domain.Role, error)
+	GetQuotaLimits(ctx context.Context) (*domain.QuotaLimits, error)
+}
+
+// Service manages digest subscriptions and sends due digests
+type Service struct {
+	config config.EmailConfig
+	store  Store
+	sender email.Sender // nil when email is disabled
+	now    func() time.Time
+}
+
+// NewService creates a new digest service. Without a sender, preferences can
+// still be saved but nothing is sent.
+func NewService(cfg config.EmailConfig, store Store, sender email.Sender) *Service {
+	return &Service{config: cfg, store: store, sender: sender, now: time.Now}
+}
+
+// Enabled reports whether digests are sent
+func (s *Service) Enabled() bool {
+	return s.sender != nil
+}
+
+// Subscription returns a user's digest preference
+func (s *Service) Subscription(ctx context.Context, userID string) (*domain.DigestSubscription, error) {
+	sub, err := s.store.GetDigestSubscription(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if sub == nil {
+		return nil, ErrUserNotFound
+	}
+	return sub, nil
+}
+
+// Subscribe sets a user's digest frequency and scope. The first digest is the
+// one at the next scheduled send time.
+func (s *Service) Subscribe(ctx context.Context, userID string, frequency domain.DigestFrequency, roleID string) (*domain.DigestSubscription, error) {
+	if !frequency.Valid() {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidFrequency, frequency)
+	}
+	if roleID != "" {
+		role, err := s.store.GetRole(ctx, roleID)
+		if err != nil {
+			return nil, err
+		}
+		if role == nil {
+			return nil, ErrRoleNotFound
+		}
+	}
+	if _, err := s.Subscription(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	var lastSentAt *time.Time
+	if frequency != domain.DigestOff {
+		scheduled := s.scheduledAt(s.now(), frequency)
+		lastSentAt = &scheduled
+	}
+	if err := s.store.SetDigestSubscription(ctx, userID, frequency, roleID, lastSentAt); err != nil {
+		return nil, err
+	}
+	return s.Subscription(ctx, userID)
+}
+
+// Build summarizes usage for the digest period ending at end
+func (s *Service) Build(ctx context.Context, frequency domain.DigestFrequency, roleID string, end time.Time) (*domain.UsageDigest, error) {
+	start := end.AddDate(0, 0, -1)
+	if frequency == domain.DigestWeekly {
+		start = end.AddDate(0, 0, -7)
+	}
+	d, err := s.store.GetDigestUsage(ctx, start, end, roleID, topN)
+	if err != nil {
+		return nil, fmt.Errorf("get digest usage: %w", err)
+	}
+	d.Frequency = frequency
+
+	var monthlyLimit float64
+	if roleID != "" {
+		role, err := s.store.GetRole(ctx, roleID)
+		if err != nil {
+			return nil, err
+		}
+		if role != nil {
+			d.RoleName = role.Name
+			if role.Policy != nil && role.Policy.BudgetPolicy.Enabled {
+				monthlyLimit = role.Policy.BudgetPolicy.MonthlyLimitUSD
+			}
+		}
+	} else {
+		limits, err := s.store.GetQuotaLimits(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if limits != nil {
+			monthlyLimit = limits.CostLimitUSD
+		}
+	}
+
+	// Burn rate over the month (UTC) the period ends in
+	monthStart := time.Date(end.Year(), end.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if !end.After(monthStart) {
+		monthStart = monthStart.AddDate(0, -1, 0)
+	}
+	monthEnd := monthStart.AddDate(0, 1, 0)
+	month, err := s.store.GetDigestUsage(ctx, monthStart, end, roleID, 0)
+	if err != nil {
+		return nil, fmt.Errorf("get month usage: %w", err)
+	}
+	d.Budget = burn(monthlyLimit, month.TotalCostUSD, monthStart, end, monthEnd)
+	return d, nil
+}
+
+// burn projects month-end spend from the spend between monthStart and now
+func burn(limit, spent float64, monthStart, now, monthEnd time.Time) domain.DigestBudget {
+	elapsedDays := now.Sub(monthStart).Hours() / 24
+	b := domain.DigestBudget{LimitUSD: limit, SpentUSD: spent, ProjectedUSD: spent, PeriodEnd: monthEnd}
+	if elapsedDays > 0 {
+		b.DailyBurnUSD = spent / elapsedDays
+		b.ProjectedUSD = spent + b.DailyBurnUSD*monthEnd.Sub(now).Hours()/24
+	}
+	return b
+}
+
+// Send emails a user their digest for the most recent period right away,
+// without affecting the schedule. Users without a subscription get a daily one.
+func (s *Service) Send(ctx context.Context, userID string) error {
+	if s.sender == nil {
+		return ErrEmailDisabled
+	}
+	sub, err := s.Subscription(ctx, userID)
+	if err != nil {
+		return err
+	}
+	frequency := sub.Frequency
+	if frequency == domain.DigestOff {
+		frequency = domain.DigestDaily
+	}
+	return s.send(ctx, sub, frequency, s.now())
+}
+
+// Run sends the digests that are due and returns how many were sent
+func (s *Service) Run(ctx context.Context) (int, error) {
+	if s.sender == nil {
+		return 0, ErrEmailDisabled
+	}
+	subs, err := s.store.ListDigestSubscriptions(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("list digest subscriptions: %w", err)
+	}
+
+	now := s.now()
+	sent := 0
+	for _, sub := range subs {
+		scheduled := s.scheduledAt(now, sub.Frequency)
+		if sub.LastSentAt != nil && !sub.LastSentAt.Before(scheduled) {
+			continue
+		}
+		claimed, err := s.store.ClaimDigest(ctx, sub.UserID, scheduled)
+		if err != nil {
+			return sent, fmt.Errorf("claim digest: %w", err)
+		}
+		if !claimed {
+			continue
+		}
+		if err := s.send(ctx, sub, sub.Frequency, scheduled); err != nil {
+			slog.Error("Failed to send usage digest", "user", sub.Email, "error", err)
+			if err := s.store.ReleaseDigest(ctx, sub.UserID, sub.LastSentAt); err != nil {
+				slog.Error("Failed to release usage digest", "user", sub.Email, "error", err)
+			}
+			continue
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+func (s *Service) send(ctx context.Context, sub *domain.DigestSubscription, frequency domain.DigestFrequency, end time.Time) error {
+	d, err := s.Build(ctx, frequency, sub.RoleID, end)
+	if err != nil {
+		return err
+	}
+	msg, err := render(d, s.config.DashboardURL)
+	if err != nil {
+		return err
+	}
+	msg.To = []string{sub.Email}
+	return s.sender.Send(ctx, msg)
+}
+
+// scheduledAt returns the most recent send time of the frequency at or before
+// now: the configured hour (UTC) every day, or on the configured weekday
+func (s *Service) scheduledAt(now time.Time, frequency domain.DigestFrequency) time.Time {
+	now = now.UTC()
+	t := time.Date(now.Year(), now.Month(), now.Day(), s.config.DigestHour, 0, 0, 0, time.UTC)
+	if t.After(now) {
+		t = t.AddDate(0, 0, -1)
+	}
+	if frequency == domain.DigestWeekly {
+		day := weekday(s.config.WeeklyDigestDay)
+		for t.Weekday() != day {
+			t = t.AddDate(0, 0, -1)
+		}
+	}
+	return t
+}
+
+// weekday parses a day name, defaulting to Monday
+func weekday(name string) time.Weekday {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(d.String(), name) || strings.EqualFold(d.String()[:3], name) {
+			return d
+		}
+	}
+	return time.Monday
+}
+
+// Start sends due digests periodically until ctx is done
+func (s *Service) Start(ctx context.Context) {
+	interval := s.config.CheckInterval
+	if interval <= 0 {
+		interval = 15 * time.Minute
+	}
+	run := func() {
+		sent, err := s.Run(ctx)
+		if err != nil {
+			slog.Error("Usage digest run failed", "error", err)
+		}
+		if sent > 0 {
+			slog.Info("Sent usage digests", "count", sent)
+		}
+	}
+
+	go func() {
+		run()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				run()
+			}
+		}
+	}()
+}
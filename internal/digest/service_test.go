@@ -0,0 +1,188 @@
+package digest
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+	"modelgate/internal/email"
+)
+
+type fakeStore struct {
+	subs  map[string]*domain.DigestSubscription
+	roles map[string]*domain.Role
+	quota *domain.QuotaLimits
+	spent float64 // Cost reported for any period
+}
+
+func (f *fakeStore) ListDigestSubscriptions(ctx context.Context) ([]*domain.DigestSubscription, error) {
+	var out []*domain.DigestSubscription
+	for _, sub := range f.subs {
+		if sub.Frequency != domain.DigestOff {
+			copied := *sub
+			out = append(out, &copied)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeStore) GetDigestSubscription(ctx context.Context, userID string) (*domain.DigestSubscription, error) {
+	sub, ok := f.subs[userID]
+	if !ok {
+		return nil, nil
+	}
+	copied := *sub
+	return &copied, nil
+}
+
+func (f *fakeStore) SetDigestSubscription(ctx context.Context, userID string, frequency domain.DigestFrequency, roleID string, lastSentAt *time.Time) error {
+	sub := f.subs[userID]
+	sub.Frequency, sub.RoleID, sub.LastSentAt = frequency, roleID, lastSentAt
+	return nil
+}
+
+func (f *fakeStore) ClaimDigest(ctx context.Context, userID string, scheduled time.Time) (bool, error) {
+	sub := f.subs[userID]
+	if sub.LastSentAt != nil && !sub.LastSentAt.Before(scheduled) {
+		return false, nil
+	}
+	sub.LastSentAt = &scheduled
+	return true, nil
+}
+
+func (f *fakeStore) ReleaseDigest(ctx context.Context, userID string, previous *time.Time) error {
+	f.subs[userID].LastSentAt = previous
+	return nil
+}
+
+func (f *fakeStore) GetDigestUsage(ctx context.Context, start, end time.Time, roleID string, top int) (*domain.UsageDigest, error) {
+	d := &domain.UsageDigest{PeriodStart: start, PeriodEnd: end, RoleID: roleID,
+		TotalRequests: 200, FailedRequests: 10, TotalCostUSD: f.spent}
+	if top > 0 {
+		d.TopModels = []domain.ModelUsage{{ModelID: "openai/gpt-4o", Requests: 150, CostUSD: f.spent}}
+		d.TopAPIKeys = []domain.APIKeyUsageStats{{APIKeyID: "k1", APIKeyName: "backend", Requests: 200, CostUSD: f.spent}}
+	}
+	return d, nil
+}
+
+func (f *fakeStore) GetRole(ctx context.Context, id string) (*domain.Role, error) {
+	return f.roles[id], nil
+}
+
+func (f *fakeStore) GetQuotaLimits(ctx context.Context) (*domain.QuotaLimits, error) {
+	return f.quota, nil
+}
+
+type fakeSender struct {
+	sent []*email.Message
+	err  error
+}
+
+func (f *fakeSender) Send(ctx context.Context, msg *email.Message) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.sent = append(f.sent, msg)
+	return nil
+}
+
+func newTestService(store *fakeStore, sender *fakeSender, now time.Time) *Service {
+	svc := NewService(config.EmailConfig{DigestHour: 8, WeeklyDigestDay: "monday"}, store, sender)
+	svc.now = func() time.Time { return now }
+	return svc
+}
+
+func TestScheduledAt(t *testing.T) {
+	svc := newTestService(&fakeStore{}, &fakeSender{}, time.Time{})
+	// Wednesday, before the send hour
+	now := time.Date(2026, 3, 11, 7, 30, 0, 0, time.UTC)
+
+	if got := svc.scheduledAt(now, domain.DigestDaily); !got.Equal(time.Date(2026, 3, 10, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("daily: got %v", got)
+	}
+	if got := svc.scheduledAt(now.Add(time.Hour), domain.DigestDaily); !got.Equal(time.Date(2026, 3, 11, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("daily after the hour: got %v", got)
+	}
+	if got := svc.scheduledAt(now, domain.DigestWeekly); !got.Equal(time.Date(2026, 3, 9, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("weekly: got %v", got)
+	}
+}
+
+func TestRunSendsDueDigestsOnce(t *testing.T) {
+	now := time.Date(2026, 3, 11, 9, 0, 0, 0, time.UTC)
+	store := &fakeStore{
+		subs: map[string]*domain.DigestSubscription{
+			"u1": {UserID: "u1", Email: "ops@example.com", Frequency: domain.DigestOff},
+		},
+		quota: &domain.QuotaLimits{CostLimitUSD: 100},
+		spent: 50,
+	}
+	sender := &fakeSender{}
+	svc := newTestService(store, sender, now)
+
+	// A new subscription waits for the next send time
+	if _, err := svc.Subscribe(context.Background(), "u1", domain.DigestDaily, ""); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	if sent, _ := svc.Run(context.Background()); sent != 0 {
+		t.Fatalf("expected nothing due right after subscribing, sent %d", sent)
+	}
+
+	svc.now = func() time.Time { return now.Add(24 * time.Hour) }
+	if sent, err := svc.Run(context.Background()); err != nil || sent != 1 {
+		t.Fatalf("expected one digest, sent %d (%v)", sent, err)
+	}
+	if sent, _ := svc.Run(context.Background()); sent != 0 {
+		t.Fatalf("expected the digest to be sent once, sent %d more", sent)
+	}
+
+	msg := sender.sent[0]
+	if msg.To[0] != "ops@example.com" || !strings.Contains(msg.Subject, "$50.00 spent") {
+		t.Fatalf("unexpected message: %v %q", msg.To, msg.Subject)
+	}
+	// $50 spent by March 12 08:00 projects well over the $100 limit
+	for _, want := range []string{"openai/gpt-4o", "backend", "5.0%", "of $100.00 (50%)", "over the monthly limit"} {
+		if !strings.Contains(msg.Text, want) {
+			t.Errorf("digest text missing %q:\n%s", want, msg.Text)
+		}
+	}
+}
+
+func TestRunRetriesFailedSends(t *testing.T) {
+	now := time.Date(2026, 3, 11, 9, 0, 0, 0, time.UTC)
+	store := &fakeStore{subs: map[string]*domain.DigestSubscription{
+		"u1": {UserID: "u1", Email: "ops@example.com", Frequency: domain.DigestWeekly},
+	}}
+	sender := &fakeSender{err: errors.New("connection refused")}
+	svc := newTestService(store, sender, now)
+
+	if sent, _ := svc.Run(context.Background()); sent != 0 {
+		t.Fatalf("expected the failed send not to count, got %d", sent)
+	}
+	if store.subs["u1"].LastSentAt != nil {
+		t.Fatal("expected a failed send to release its claim")
+	}
+	sender.err = nil
+	if sent, _ := svc.Run(context.Background()); sent != 1 {
+		t.Fatalf("expected the digest to be retried, got %d", sent)
+	}
+}
+
+func TestSubscribeValidates(t *testing.T) {
+	store := &fakeStore{subs: map[string]*domain.DigestSubscription{"u1": {UserID: "u1"}}}
+	svc := newTestService(store, &fakeSender{}, time.Now())
+
+	if _, err := svc.Subscribe(context.Background(), "u1", "hourly", ""); !errors.Is(err, ErrInvalidFrequency) {
+		t.Errorf("expected ErrInvalidFrequency, got %v", err)
+	}
+	if _, err := svc.Subscribe(context.Background(), "u1", domain.DigestDaily, "missing"); !errors.Is(err, ErrRoleNotFound) {
+		t.Errorf("expected ErrRoleNotFound, got %v", err)
+	}
+	if _, err := svc.Subscribe(context.Background(), "nobody", domain.DigestDaily, ""); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("expected ErrUserNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,245 @@
+// Package discovery keeps the model catalog (available_models) current
+// without anyone refreshing it by hand. At startup and then periodically it
+// lists every enabled provider's models, fills in capabilities and prices,
+// and reports models that were added, deprecated or removed.
+package discovery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"modelgate/internal/audit"
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+)
+
+// Store is the persistence used by the service (implemented by postgres.TenantStore)
+type Store interface {
+	ListProviderConfigs(ctx context.Context) ([]*domain.ProviderConfig, error)
+	ListCatalogModels(ctx context.Context, provider string) ([]domain.CatalogModel, error)
+	SyncCatalogModels(ctx context.Context, provider string, listed []domain.ModelInfo, deprecated, removed []string) error
+}
+
+// Catalog lists a provider's models and describes them (implemented by gateway.Service)
+type Catalog interface {
+	ListProviderModels(ctx context.Context, tenantSlug string, provider domain.Provider, providerCfg *domain.ProviderConfig) ([]domain.ModelInfo, error)
+	DescribeModel(ctx context.Context, m domain.ModelInfo) domain.ModelInfo
+}
+
+// Auditor records catalog changes (implemented by audit.Service)
+type Auditor interface {
+	LogSuccess(ctx context.Context, entry audit.LogEntry) error
+}
+
+// managedProviders keep their catalog entries in sync themselves, from the
+// installed Ollama models and the registered custom models
+var managedProviders = []domain.Provider{domain.ProviderOllama, domain.ProviderCustom}
+
+// Service runs model discovery
+type Service struct {
+	cfg        config.ModelDiscoveryConfig
+	store      Store
+	catalog    Catalog
+	auditor    Auditor // nil to skip the audit log
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+// NewService creates a model discovery service
+func NewService(cfg config.ModelDiscoveryConfig, store Store, catalog Catalog, auditor Auditor) *Service {
+	return &Service{
+		cfg:        cfg,
+		store:      store,
+		catalog:    catalog,
+		auditor:    auditor,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		now:        time.Now,
+	}
+}
+
+// Start runs discovery now and then every interval until ctx is cancelled
+func (s *Service) Start(ctx context.Context) {
+	go func() {
+		s.Run(ctx)
+		ticker := time.NewTicker(s.cfg.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.Run(ctx)
+			}
+		}
+	}()
+}
+
+// Run lists every enabled provider into the catalog and returns a report
+// per provider. A provider that can't be listed keeps its catalog as it was.
+func (s *Service) Run(ctx context.Context) []domain.ModelDiscoveryReport {
+	configs, err := s.store.ListProviderConfigs(ctx)
+	if err != nil {
+		slog.Error("Model discovery failed to list providers", "error", err)
+		return nil
+	}
+
+	var reports []domain.ModelDiscoveryReport
+	for _, cfg := range configs {
+		if !cfg.Enabled || slices.Contains(managedProviders, cfg.Provider) {
+			continue
+		}
+		report := s.discover(ctx, cfg)
+		if report.Error != "" {
+			slog.Warn("Model discovery failed for provider", "provider", cfg.Provider, "error", report.Error)
+		} else if report.Changed() {
+			slog.Info("Model discovery updated catalog", "provider", cfg.Provider,
+				"added", len(report.Added), "deprecated", len(report.Deprecated), "removed", len(report.Removed))
+			s.audit(ctx, report)
+		}
+		reports = append(reports, report)
+	}
+
+	var changed []domain.ModelDiscoveryReport
+	for _, r := range reports {
+		if r.Changed() {
+			changed = append(changed, r)
+		}
+	}
+	if len(changed) > 0 {
+		s.postWebhook(ctx, changed)
+	}
+	return reports
+}
+
+// discover syncs one provider's catalog with its listing
+func (s *Service) discover(ctx context.Context, cfg *domain.ProviderConfig) domain.ModelDiscoveryReport {
+	report := domain.ModelDiscoveryReport{Provider: cfg.Provider}
+
+	// Single-tenant mode - use "default" as tenant slug
+	models, err := s.catalog.ListProviderModels(ctx, "default", cfg.Provider, cfg)
+	if err != nil {
+		report.Error = err.Error()
+		return report
+	}
+	for i, m := range models {
+		m = s.catalog.DescribeModel(ctx, m)
+		if m.Provider == "" {
+			m.Provider = cfg.Provider
+		}
+		if m.Name == "" {
+			m.Name = m.ID
+		}
+		m.Enabled = true
+		models[i] = m
+	}
+	report.Listed = len(models)
+
+	existing, err := s.store.ListCatalogModels(ctx, string(cfg.Provider))
+	if err != nil {
+		report.Error = fmt.Sprintf("list catalog: %v", err)
+		return report
+	}
+	report.Added, report.Deprecated, report.Removed = diffCatalog(existing, models, s.now(), s.cfg.RemoveAfter)
+
+	if err := s.store.SyncCatalogModels(ctx, string(cfg.Provider), models, report.Deprecated, report.Removed); err != nil {
+		report.Error = fmt.Sprintf("save catalog: %v", err)
+		report.Added, report.Deprecated, report.Removed = nil, nil, nil
+	}
+	return report
+}
+
+// diffCatalog compares a provider's listing with its catalog entries. Listed
+// models missing from the catalog are added; available models the provider
+// no longer lists are deprecated, and deprecated ones unlisted for
+// removeAfter are removed.
+func diffCatalog(catalog []domain.CatalogModel, listed []domain.ModelInfo, now time.Time, removeAfter time.Duration) (added, deprecated, removed []string) {
+	inCatalog := make(map[string]domain.CatalogModel, len(catalog))
+	for _, m := range catalog {
+		inCatalog[m.ID] = m
+	}
+	isListed := make(map[string]bool, len(listed))
+	for _, m := range listed {
+		isListed[m.ID] = true
+		if c, ok := inCatalog[m.ID]; !ok || !c.Available {
+			added = append(added, m.ID)
+		}
+	}
+	for _, c := range catalog {
+		if !c.Available || isListed[c.ID] {
+			continue
+		}
+		switch {
+		case !c.Deprecated:
+			deprecated = append(deprecated, c.ID)
+		case now.Sub(c.FetchedAt) >= removeAfter:
+			removed = append(removed, c.ID)
+		}
+	}
+	slices.Sort(added)
+	slices.Sort(deprecated)
+	slices.Sort(removed)
+	return added, deprecated, removed
+}
+
+// audit records a provider's catalog changes in the audit log
+func (s *Service) audit(ctx context.Context, r domain.ModelDiscoveryReport) {
+	if s.auditor == nil {
+		return
+	}
+	err := s.auditor.LogSuccess(ctx, audit.LogEntry{
+		TenantSlug:   "default",
+		Action:       domain.AuditActionUpdate,
+		ResourceType: domain.AuditResourceProvider,
+		ResourceID:   string(r.Provider),
+		ResourceName: string(r.Provider),
+		Actor:        audit.Actor{Email: "model-discovery", Type: "system"},
+		Details:      map[string]any{"source": "model_discovery", "listed": r.Listed},
+		NewValue: map[string]any{
+			"added":      r.Added,
+			"deprecated": r.Deprecated,
+			"removed":    r.Removed,
+		},
+	})
+	if err != nil {
+		slog.Warn("Failed to write model discovery audit log", "provider", r.Provider, "error", err)
+	}
+}
+
+// discoveryEvent is the payload posted to the notification webhook
+type discoveryEvent struct {
+	Event   string                        `json:"event"`
+	Reports []domain.ModelDiscoveryReport `json:"reports"`
+}
+
+// postWebhook posts the run's changes to the notification webhook. Failures
+// are logged; the event is not retried.
+func (s *Service) postWebhook(ctx context.Context, reports []domain.ModelDiscoveryReport) {
+	if s.cfg.NotifyWebhook == "" {
+		return
+	}
+	body, err := json.Marshal(discoveryEvent{Event: "model_discovery.changed", Reports: reports})
+	if err != nil {
+		return
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.NotifyWebhook, bytes.NewReader(body))
+	if err != nil {
+		slog.Error("Failed to build model discovery webhook", "error", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		slog.Error("Failed to send model discovery webhook", "error", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		slog.Error("Model discovery webhook failed", "status", resp.Status)
+	}
+}
@@ -0,0 +1,129 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"modelgate/internal/audit"
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+)
+
+type fakeStore struct {
+	configs []*domain.ProviderConfig
+	catalog map[string][]domain.CatalogModel
+	synced  map[string][]domain.ModelInfo
+}
+
+func (f *fakeStore) ListProviderConfigs(ctx context.Context) ([]*domain.ProviderConfig, error) {
+	return f.configs, nil
+}
+
+func (f *fakeStore) ListCatalogModels(ctx context.Context, provider string) ([]domain.CatalogModel, error) {
+	return f.catalog[provider], nil
+}
+
+func (f *fakeStore) SyncCatalogModels(ctx context.Context, provider string, listed []domain.ModelInfo, deprecated, removed []string) error {
+	f.synced[provider] = listed
+	return nil
+}
+
+type fakeCatalog struct {
+	models map[domain.Provider][]domain.ModelInfo
+}
+
+func (f *fakeCatalog) ListProviderModels(ctx context.Context, tenantSlug string, provider domain.Provider, cfg *domain.ProviderConfig) ([]domain.ModelInfo, error) {
+	models, ok := f.models[provider]
+	if !ok {
+		return nil, errors.New("unauthorized")
+	}
+	return models, nil
+}
+
+func (f *fakeCatalog) DescribeModel(ctx context.Context, m domain.ModelInfo) domain.ModelInfo {
+	if m.ID == "gpt-4o" {
+		m.SupportsVision, m.InputCostPer1M = true, 2.5
+	}
+	return m
+}
+
+type fakeAuditor struct{ entries []audit.LogEntry }
+
+func (f *fakeAuditor) LogSuccess(ctx context.Context, entry audit.LogEntry) error {
+	f.entries = append(f.entries, entry)
+	return nil
+}
+
+func TestModelDiscovery(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	store := &fakeStore{
+		configs: []*domain.ProviderConfig{
+			{Provider: domain.ProviderOpenAI, Enabled: true},
+			{Provider: domain.ProviderAnthropic, Enabled: true}, // Listing fails
+			{Provider: domain.ProviderGroq, Enabled: false},
+			{Provider: domain.ProviderOllama, Enabled: true}, // Synced by the Ollama service
+		},
+		catalog: map[string][]domain.CatalogModel{
+			"openai": {
+				{ID: "gpt-4", Available: true, FetchedAt: now.Add(-time.Hour)},
+				{ID: "gpt-3.5-turbo", Available: true, Deprecated: true, FetchedAt: now.Add(-8 * 24 * time.Hour)},
+				{ID: "davinci", Available: true, Deprecated: true, FetchedAt: now.Add(-24 * time.Hour)},
+				{ID: "o1-preview", Available: false, Deprecated: true, FetchedAt: now.Add(-30 * 24 * time.Hour)},
+				{ID: "gpt-4o-mini", Available: true, FetchedAt: now.Add(-time.Hour)},
+			},
+		},
+		synced: map[string][]domain.ModelInfo{},
+	}
+	catalog := &fakeCatalog{models: map[domain.Provider][]domain.ModelInfo{
+		domain.ProviderOpenAI: {{ID: "gpt-4o"}, {ID: "gpt-4o-mini"}, {ID: "o1-preview"}},
+		domain.ProviderOllama: {{ID: "llama3"}},
+	}}
+	auditor := &fakeAuditor{}
+
+	var posted discoveryEvent
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&posted)
+	}))
+	defer webhook.Close()
+
+	svc := NewService(config.ModelDiscoveryConfig{Enabled: true, RemoveAfter: 7 * 24 * time.Hour, NotifyWebhook: webhook.URL}, store, catalog, auditor)
+	svc.now = func() time.Time { return now }
+
+	reports := svc.Run(context.Background())
+	if len(reports) != 2 {
+		t.Fatalf("expected reports for openai and anthropic only, got %+v", reports)
+	}
+	openai := reports[1]
+	if reports[0].Provider == domain.ProviderOpenAI {
+		openai = reports[0]
+	}
+	// Models returning after removal count as added; gpt-4 stopped being
+	// listed, and gpt-3.5-turbo has been unlisted past remove_after
+	if !slices.Equal(openai.Added, []string{"gpt-4o", "o1-preview"}) ||
+		!slices.Equal(openai.Deprecated, []string{"gpt-4"}) ||
+		!slices.Equal(openai.Removed, []string{"gpt-3.5-turbo"}) || openai.Listed != 3 {
+		t.Fatalf("unexpected openai report %+v", openai)
+	}
+
+	synced := store.synced["openai"]
+	if len(synced) != 3 || !synced[0].SupportsVision || synced[0].InputCostPer1M != 2.5 ||
+		synced[0].Provider != domain.ProviderOpenAI || synced[0].Name != "gpt-4o" || !synced[0].Enabled {
+		t.Fatalf("expected listed models to be enriched, got %+v", synced)
+	}
+	if _, ok := store.synced["anthropic"]; ok {
+		t.Fatal("expected a failed listing to leave the catalog alone")
+	}
+
+	if len(auditor.entries) != 1 || auditor.entries[0].ResourceName != "openai" || auditor.entries[0].Actor.Type != "system" {
+		t.Fatalf("expected one audit entry for openai, got %+v", auditor.entries)
+	}
+	if posted.Event != "model_discovery.changed" || len(posted.Reports) != 1 || posted.Reports[0].Provider != domain.ProviderOpenAI {
+		t.Fatalf("unexpected webhook payload %+v", posted)
+	}
+}
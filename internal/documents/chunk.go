@@ -0,0 +1,53 @@
+package documents
+
+import (
+	"strings"
+	"unicode"
+)
+
+// chunkText splits text into pieces of at most size characters, each
+// starting with the last overlap characters of the previous one. Pieces end
+// at a paragraph, sentence or word boundary when one falls in their last
+// fifth, so retrieved chunks read as whole passages.
+func chunkText(text string, size, overlap int) []string {
+	runes := []rune(strings.TrimSpace(text))
+	var chunks []string
+	for start := 0; start < len(runes); {
+		end := min(start+size, len(runes))
+		if end < len(runes) {
+			end = breakPoint(runes, start+size*4/5, end)
+		}
+		if chunk := strings.TrimSpace(string(runes[start:end])); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+		if end >= len(runes) {
+			break
+		}
+
+		// Overlap from the start of a word, always moving forward
+		next := max(end-overlap, start+1)
+		for next < end && !unicode.IsSpace(runes[next-1]) {
+			next++
+		}
+		start = next
+	}
+	return chunks
+}
+
+// breakPoint returns where a chunk ending before limit should end: after the
+// last paragraph break, sentence end or space at or after from, or at limit
+func breakPoint(runes []rune, from, limit int) int {
+	from = max(from, 1)
+	for _, isBreak := range []func(i int) bool{
+		func(i int) bool { return runes[i-1] == '\n' && i >= 2 && runes[i-2] == '\n' },
+		func(i int) bool { return unicode.IsSpace(runes[i]) && strings.ContainsRune(".!?", runes[i-1]) },
+		func(i int) bool { return unicode.IsSpace(runes[i]) },
+	} {
+		for i := limit - 1; i >= from; i-- {
+			if isBreak(i) {
+				return i
+			}
+		}
+	}
+	return limit
+}
@@ -0,0 +1,111 @@
+package documents
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// minPageText is the length under which a page's extracted text isn't taken
+// to be its content, so its images are transcribed instead
+const minPageText = 40
+
+// page is the text of one page of a document, or of the whole file for
+// formats without pages
+type page struct {
+	number int // 1-based, 0 for formats without pages
+	text   string
+	images []pageImage // To transcribe when the page has no text of its own
+}
+
+// kind is how a document's text is extracted
+type kind int
+
+const (
+	kindUnsupported kind = iota
+	kindText
+	kindHTML
+	kindPDF
+	kindImage
+)
+
+// detectType returns the media type of a document from the declared type,
+// the file name and, failing both, its content
+func detectType(filename, declared string, data []byte) string {
+	if mt, _, err := mime.ParseMediaType(declared); err == nil && mt != "application/octet-stream" {
+		return mt
+	}
+	if mt, _, err := mime.ParseMediaType(mime.TypeByExtension(strings.ToLower(filepath.Ext(filename)))); err == nil {
+		return mt
+	}
+	mt, _, _ := mime.ParseMediaType(http.DetectContentType(data))
+	return mt
+}
+
+func kindOf(mediaType string) kind {
+	switch {
+	case mediaType == "application/pdf":
+		return kindPDF
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml":
+		return kindHTML
+	case strings.HasPrefix(mediaType, "text/"), mediaType == "application/json", mediaType == "application/xml",
+		mediaType == "application/x-yaml", mediaType == "application/yaml":
+		return kindText
+	case mediaType == "image/png", mediaType == "image/jpeg", mediaType == "image/gif", mediaType == "image/webp":
+		return kindImage
+	}
+	return kindUnsupported
+}
+
+// extract returns the pages of a document. Images come back as a page to
+// transcribe.
+func extract(data []byte, mediaType string) ([]page, error) {
+	switch kindOf(mediaType) {
+	case kindPDF:
+		return extractPDF(data)
+	case kindImage:
+		return []page{{images: []pageImage{{data: data, mediaType: mediaType}}}}, nil
+	case kindText, kindHTML:
+		if !utf8.Valid(data) {
+			return nil, errors.New("text documents must be UTF-8")
+		}
+		text := string(data)
+		if kindOf(mediaType) == kindHTML {
+			text = htmlText(text)
+		}
+		return []page{{text: cleanText(text)}}, nil
+	}
+	return nil, ErrUnsupportedType
+}
+
+var (
+	htmlSkipped = regexp.MustCompile(`(?is)<(script|style|head)\b.*?</(script|style|head)>`)
+	htmlBlock   = regexp.MustCompile(`(?i)<(br|/p|/div|/h[1-6]|/li|/tr|/title)\b[^>]*>`)
+	htmlTag     = regexp.MustCompile(`(?s)<[^>]*>`)
+	htmlEntity  = strings.NewReplacer("&amp;", "&", "&lt;", "<", "&gt;", ">", "&quot;", `"`, "&#39;", "'", "&nbsp;", " ")
+)
+
+// htmlText returns the visible text of an HTML page, with line breaks after blocks
+func htmlText(html string) string {
+	html = htmlSkipped.ReplaceAllString(html, "")
+	html = htmlBlock.ReplaceAllString(html, "\n")
+	return htmlEntity.Replace(htmlTag.ReplaceAllString(html, ""))
+}
+
+var (
+	spaceRun   = regexp.MustCompile(`[ \t\f\v\r]+`)
+	newlineRun = regexp.MustCompile(`\n\s*\n\s*\n+`)
+)
+
+// cleanText collapses runs of spaces and blank lines
+func cleanText(text string) string {
+	text = strings.ToValidUTF8(text, "")
+	text = spaceRun.ReplaceAllString(text, " ")
+	text = strings.ReplaceAll(text, " \n", "\n")
+	text = strings.ReplaceAll(text, "\n ", "\n")
+	return strings.TrimSpace(newlineRun.ReplaceAllString(text, "\n\n"))
+}
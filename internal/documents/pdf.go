@@ -0,0 +1,915 @@
+package documents
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/zlib"
+	"encoding/ascii85"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// This file reads the text of PDFs: enough of the format to walk the page
+// tree, decode content streams and follow text operators, with ToUnicode
+// CMaps for embedded fonts. Layout is approximated with line breaks where the
+// text moves down. Pages without extractable text yield the JPEG images they
+// draw, which is how scanners store pages, for OCR.
+
+const (
+	// maxDecodedStream bounds a decompressed stream, against zip bombs
+	maxDecodedStream = 64 << 20
+
+	// maxPDFDepth bounds nesting of objects and form XObjects
+	maxPDFDepth = 64
+
+	// maxPDFPages bounds the page tree walk
+	maxPDFPages = 10000
+)
+
+// errEncryptedPDF is returned for PDFs whose content is encrypted
+var errEncryptedPDF = errors.New("encrypted PDFs are not supported")
+
+type (
+	pdfName    string
+	pdfString  string // Raw bytes of a literal or hex string
+	pdfKeyword string // Operators and keywords such as obj, stream and R
+	pdfDict    map[pdfName]any
+	pdfRef     struct{ num, gen int }
+)
+
+type pdfStream struct {
+	dict pdfDict
+	raw  []byte
+}
+
+// pdfLexer reads PDF objects from data
+type pdfLexer struct {
+	data  []byte
+	pos   int
+	refs  bool // Read "n g R" as references (not in content streams)
+	depth int
+}
+
+func isPDFSpace(c byte) bool {
+	return c == 0 || c == '\t' || c == '\n' || c == '\f' || c == '\r' || c == ' '
+}
+
+func isPDFDelim(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+func (l *pdfLexer) skipSpace() {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		if isPDFSpace(c) {
+			l.pos++
+			continue
+		}
+		if c == '%' {
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+			continue
+		}
+		return
+	}
+}
+
+// regular reads a token of regular characters
+func (l *pdfLexer) regular() string {
+	start := l.pos
+	for l.pos < len(l.data) && !isPDFSpace(l.data[l.pos]) && !isPDFDelim(l.data[l.pos]) {
+		l.pos++
+	}
+	return string(l.data[start:l.pos])
+}
+
+// object reads the next object. Returns io.EOF at the end of the data.
+func (l *pdfLexer) object() (any, error) {
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return nil, io.EOF
+	}
+	l.depth++
+	defer func() { l.depth-- }()
+	if l.depth > maxPDFDepth {
+		return nil, errors.New("objects nested too deeply")
+	}
+
+	switch c := l.data[l.pos]; c {
+	case '/':
+		l.pos++
+		return pdfName(decodeNameEscapes(l.regular())), nil
+	case '(':
+		return l.literalString(), nil
+	case '<':
+		if l.pos+1 < len(l.data) && l.data[l.pos+1] == '<' {
+			l.pos += 2
+			return l.dict()
+		}
+		return l.hexString(), nil
+	case '[':
+		l.pos++
+		var arr []any
+		for {
+			l.skipSpace()
+			if l.pos >= len(l.data) {
+				return arr, nil
+			}
+			if l.data[l.pos] == ']' {
+				l.pos++
+				return arr, nil
+			}
+			v, err := l.object()
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+	case ']', '>', ')', '{', '}':
+		l.pos++
+		return pdfKeyword(string(c)), nil
+	}
+
+	tok := l.regular()
+	if tok == "" {
+		l.pos++
+		return pdfKeyword(""), nil
+	}
+	switch tok {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	n, err := strconv.ParseFloat(tok, 64)
+	if err != nil {
+		return pdfKeyword(tok), nil
+	}
+	if l.refs && !strings.ContainsAny(tok, ".-+") {
+		save := l.pos
+		l.skipSpace()
+		if gen, err := strconv.Atoi(l.regular()); err == nil {
+			l.skipSpace()
+			if l.regular() == "R" {
+				return pdfRef{int(n), gen}, nil
+			}
+		}
+		l.pos = save
+	}
+	return n, nil
+}
+
+func (l *pdfLexer) dict() (pdfDict, error) {
+	d := pdfDict{}
+	for {
+		l.skipSpace()
+		if l.pos >= len(l.data) {
+			return d, nil
+		}
+		if l.data[l.pos] == '>' {
+			l.pos += min(2, len(l.data)-l.pos)
+			return d, nil
+		}
+		key, err := l.object()
+		if err != nil {
+			return nil, err
+		}
+		value, err := l.object()
+		if err != nil {
+			return nil, err
+		}
+		if name, ok := key.(pdfName); ok {
+			d[name] = value
+		}
+	}
+}
+
+func (l *pdfLexer) literalString() pdfString {
+	l.pos++ // (
+	var b []byte
+	depth := 1
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				return pdfString(b)
+			}
+		case '\\':
+			if l.pos >= len(l.data) {
+				return pdfString(b)
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r':
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+				continue
+			case '\n':
+				continue
+			default:
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						v = v*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					c = byte(v)
+				} else {
+					c = e
+				}
+			}
+		}
+		b = append(b, c)
+	}
+	return pdfString(b)
+}
+
+func (l *pdfLexer) hexString() pdfString {
+	l.pos++ // <
+	var digits []byte
+	for l.pos < len(l.data) && l.data[l.pos] != '>' {
+		if c := l.data[l.pos]; !isPDFSpace(c) {
+			digits = append(digits, c)
+		}
+		l.pos++
+	}
+	l.pos++ // >
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	b := make([]byte, len(digits)/2)
+	n, _ := hex.Decode(b, digits)
+	return pdfString(b[:n])
+}
+
+// decodeNameEscapes decodes #xx escapes in a name
+func decodeNameEscapes(s string) string {
+	if !strings.Contains(s, "#") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '#' && i+2 < len(s) {
+			if v, err := strconv.ParseUint(s[i+1:i+3], 16, 8); err == nil {
+				b.WriteByte(byte(v))
+				i += 2
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// pdfDoc holds the objects of a PDF by number
+type pdfDoc struct {
+	objects map[int]any
+}
+
+var pdfObjectHeader = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+
+// parsePDF reads every object of a PDF. The cross-reference table isn't
+// used: objects are found by their headers, later definitions replacing
+// earlier ones as in incremental updates, then object streams are unpacked.
+func parsePDF(data []byte) (*pdfDoc, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data, " \t\r\n"), []byte("%PDF-")) {
+		return nil, errors.New("not a PDF file")
+	}
+	doc := &pdfDoc{objects: map[int]any{}}
+	skipUntil := 0
+	for _, m := range pdfObjectHeader.FindAllSubmatchIndex(data, -1) {
+		if m[0] < skipUntil {
+			continue // Inside the stream of the previous object
+		}
+		num, _ := strconv.Atoi(string(data[m[2]:m[3]]))
+		l := &pdfLexer{data: data, pos: m[1], refs: true}
+		obj, err := l.object()
+		if err != nil {
+			continue
+		}
+		if dict, ok := obj.(pdfDict); ok {
+			save := l.pos
+			l.skipSpace()
+			if l.regular() == "stream" {
+				raw, end := streamData(data, l.pos, dict)
+				obj = &pdfStream{dict: dict, raw: raw}
+				skipUntil = end
+			} else {
+				l.pos = save
+			}
+		}
+		doc.objects[num] = obj
+	}
+	if len(doc.objects) == 0 {
+		return nil, errors.New("no objects found in the PDF")
+	}
+
+	for _, obj := range doc.objects {
+		if s, ok := obj.(*pdfStream); ok && s.dict["Type"] == pdfName("ObjStm") {
+			doc.unpackObjectStream(s)
+		}
+	}
+	for _, obj := range doc.objects {
+		if s, ok := obj.(*pdfStream); ok && s.dict["Type"] == pdfName("XRef") && s.dict["Encrypt"] != nil {
+			return nil, errEncryptedPDF
+		}
+	}
+	for _, i := range indexAll(data, []byte("trailer")) {
+		l := &pdfLexer{data: data, pos: i + len("trailer"), refs: true}
+		if trailer, err := l.object(); err == nil {
+			if dict, ok := trailer.(pdfDict); ok && dict["Encrypt"] != nil {
+				return nil, errEncryptedPDF
+			}
+		}
+	}
+	return doc, nil
+}
+
+func indexAll(data, sep []byte) []int {
+	var found []int
+	for start := 0; ; {
+		i := bytes.Index(data[start:], sep)
+		if i < 0 {
+			return found
+		}
+		found = append(found, start+i)
+		start += i + len(sep)
+	}
+}
+
+// streamData returns the bytes of a stream starting after its "stream"
+// keyword at pos, and the offset where the stream ends
+func streamData(data []byte, pos int, dict pdfDict) ([]byte, int) {
+	if pos < len(data) && data[pos] == '\r' {
+		pos++
+	}
+	if pos < len(data) && data[pos] == '\n' {
+		pos++
+	}
+	if n, ok := dict["Length"].(float64); ok && n >= 0 && pos+int(n) <= len(data) {
+		end := pos + int(n)
+		rest := bytes.TrimLeft(data[end:min(end+32, len(data))], " \t\r\n")
+		if bytes.HasPrefix(rest, []byte("endstream")) {
+			return data[pos:end], end
+		}
+	}
+	// The length is indirect or wrong: the stream ends at endstream
+	end := bytes.Index(data[pos:], []byte("endstream"))
+	if end < 0 {
+		return data[pos:], len(data)
+	}
+	raw := bytes.TrimRight(data[pos:pos+end], "\r\n")
+	return raw, pos + end
+}
+
+// unpackObjectStream adds the objects compressed in an object stream, unless
+// they are defined directly
+func (d *pdfDoc) unpackObjectStream(s *pdfStream) {
+	data, _, err := d.decodeStream(s)
+	if err != nil {
+		return
+	}
+	n, _ := d.resolve(s.dict["N"]).(float64)
+	first, _ := d.resolve(s.dict["First"]).(float64)
+	if first < 0 || int(first) > len(data) {
+		return
+	}
+	header := &pdfLexer{data: data[:int(first)]}
+	for i := 0; i < int(n); i++ {
+		num, err1 := header.object()
+		offset, err2 := header.object()
+		numF, ok1 := num.(float64)
+		offsetF, ok2 := offset.(float64)
+		if err1 != nil || err2 != nil || !ok1 || !ok2 {
+			return
+		}
+		if _, exists := d.objects[int(numF)]; exists {
+			continue
+		}
+		pos := int(first) + int(offsetF)
+		if pos < 0 || pos >= len(data) {
+			continue
+		}
+		l := &pdfLexer{data: data, pos: pos, refs: true}
+		if obj, err := l.object(); err == nil {
+			d.objects[int(numF)] = obj
+		}
+	}
+}
+
+// resolve follows references
+func (d *pdfDoc) resolve(v any) any {
+	for i := 0; i < maxPDFDepth; i++ {
+		ref, ok := v.(pdfRef)
+		if !ok {
+			return v
+		}
+		v = d.objects[ref.num]
+	}
+	return nil
+}
+
+func (d *pdfDoc) dict(v any) pdfDict {
+	switch v := d.resolve(v).(type) {
+	case pdfDict:
+		return v
+	case *pdfStream:
+		return v.dict
+	}
+	return nil
+}
+
+// decodeStream applies a stream's filters. Image filters are left in place
+// and returned, so a JPEG comes back as its file with "DCTDecode".
+func (d *pdfDoc) decodeStream(s *pdfStream) ([]byte, pdfName, error) {
+	var filters []pdfName
+	switch f := d.resolve(s.dict["Filter"]).(type) {
+	case pdfName:
+		filters = []pdfName{f}
+	case []any:
+		for _, v := range f {
+			if name, ok := d.resolve(v).(pdfName); ok {
+				filters = append(filters, name)
+			}
+		}
+	}
+
+	data := s.raw
+	for _, f := range filters {
+		var err error
+		switch f {
+		case "FlateDecode", "Fl":
+			data, err = inflate(data)
+		case "ASCIIHexDecode", "AHx":
+			l := &pdfLexer{data: append(append([]byte("<"), data...), '>')}
+			data = []byte(l.hexString())
+		case "ASCII85Decode", "A85":
+			data, err = decodeASCII85(data)
+		case "DCTDecode", "DCT", "JPXDecode", "CCITTFaxDecode", "JBIG2Decode":
+			return data, f, nil
+		default:
+			err = fmt.Errorf("unsupported filter %s", f)
+		}
+		if err != nil {
+			return nil, "", err
+		}
+	}
+	return data, "", nil
+}
+
+func inflate(data []byte) ([]byte, error) {
+	var r io.Reader
+	if zr, err := zlib.NewReader(bytes.NewReader(data)); err == nil {
+		r = zr
+	} else {
+		r = flate.NewReader(bytes.NewReader(data))
+	}
+	out, err := io.ReadAll(io.LimitReader(r, maxDecodedStream+1))
+	if len(out) > maxDecodedStream {
+		return nil, errors.New("stream too large")
+	}
+	if err != nil && len(out) == 0 {
+		return nil, err
+	}
+	// Streams are often truncated or missing their checksum; keep what inflated
+	return out, nil
+}
+
+func decodeASCII85(data []byte) ([]byte, error) {
+	data = bytes.TrimSpace(data)
+	data = bytes.TrimPrefix(data, []byte("<~"))
+	if i := bytes.Index(data, []byte("~>")); i >= 0 {
+		data = data[:i]
+	}
+	out := make([]byte, 4*len(data)/5+4)
+	n, _, err := ascii85.Decode(out, data, true)
+	return out[:n], err
+}
+
+// pdfPage is a leaf of the page tree with its inherited resources
+type pdfPage struct {
+	dict      pdfDict
+	resources pdfDict
+}
+
+// pages returns the pages in order, from the catalog's page tree or, when
+// there is none, every page object by number
+func (d *pdfDoc) pages() []pdfPage {
+	nums := make([]int, 0, len(d.objects))
+	for num := range d.objects {
+		nums = append(nums, num)
+	}
+	sort.Ints(nums)
+
+	var pages []pdfPage
+	visited := map[pdfRef]bool{}
+	var walk func(node any, resources pdfDict, depth int)
+	walk = func(node any, resources pdfDict, depth int) {
+		if ref, ok := node.(pdfRef); ok {
+			if visited[ref] {
+				return
+			}
+			visited[ref] = true
+		}
+		dict := d.dict(node)
+		if dict == nil || depth > maxPDFDepth || len(pages) >= maxPDFPages {
+			return
+		}
+		if r := d.dict(dict["Resources"]); r != nil {
+			resources = r
+		}
+		if kids, ok := d.resolve(dict["Kids"]).([]any); ok {
+			for _, kid := range kids {
+				walk(kid, resources, depth+1)
+			}
+			return
+		}
+		if dict["Type"] == pdfName("Page") || dict["Contents"] != nil {
+			pages = append(pages, pdfPage{dict: dict, resources: resources})
+		}
+	}
+	for _, num := range nums {
+		if dict := d.dict(d.objects[num]); dict != nil && dict["Type"] == pdfName("Catalog") {
+			walk(dict["Pages"], nil, 0)
+			break
+		}
+	}
+	if len(pages) > 0 {
+		return pages
+	}
+	for _, num := range nums {
+		if dict := d.dict(d.objects[num]); dict != nil && dict["Type"] == pdfName("Page") {
+			pages = append(pages, pdfPage{dict: dict, resources: d.dict(dict["Resources"])})
+		}
+	}
+	return pages
+}
+
+// pageContent returns the decoded content streams of a page
+func (d *pdfDoc) pageContent(page pdfPage) []byte {
+	var parts []any
+	switch c := d.resolve(page.dict["Contents"]).(type) {
+	case []any:
+		parts = c
+	case *pdfStream:
+		parts = []any{c}
+	}
+	var content []byte
+	for _, part := range parts {
+		s, ok := d.resolve(part).(*pdfStream)
+		if !ok {
+			continue
+		}
+		data, filter, err := d.decodeStream(s)
+		if err != nil || filter != "" {
+			continue
+		}
+		content = append(append(content, data...), '\n')
+	}
+	return content
+}
+
+// pdfFont decodes the strings shown in a font
+type pdfFont struct {
+	codeLen   int // Bytes per character code of the CMap
+	toUnicode map[uint32]string
+}
+
+// font returns the decoder of a font resource
+func (d *pdfDoc) font(v any) *pdfFont {
+	dict := d.dict(v)
+	if dict == nil {
+		return nil
+	}
+	font := &pdfFont{codeLen: 1}
+	if dict["Subtype"] == pdfName("Type0") {
+		font.codeLen = 2
+	}
+	if s, ok := d.resolve(dict["ToUnicode"]).(*pdfStream); ok {
+		if data, filter, err := d.decodeStream(s); err == nil && filter == "" {
+			font.toUnicode, font.codeLen = parseToUnicode(data, font.codeLen)
+		}
+	}
+	return font
+}
+
+// parseToUnicode reads the bfchar and bfrange mappings of a ToUnicode CMap
+func parseToUnicode(data []byte, codeLen int) (map[uint32]string, int) {
+	m := map[uint32]string{}
+	l := &pdfLexer{data: data}
+	var operands []any
+	setCodeLen := func(src pdfString) {
+		if len(src) > 0 && len(src) <= 4 {
+			codeLen = len(src)
+		}
+	}
+	for {
+		tok, err := l.object()
+		if err != nil {
+			return m, codeLen
+		}
+		kw, ok := tok.(pdfKeyword)
+		if !ok {
+			operands = append(operands, tok)
+			continue
+		}
+		switch kw {
+		case "endcodespacerange":
+			if len(operands) > 0 {
+				if src, ok := operands[0].(pdfString); ok {
+					setCodeLen(src)
+				}
+			}
+		case "endbfchar":
+			for i := 0; i+1 < len(operands); i += 2 {
+				src, ok1 := operands[i].(pdfString)
+				dst, ok2 := operands[i+1].(pdfString)
+				if ok1 && ok2 {
+					m[codeOf(src)] = utf16BE(dst)
+				}
+			}
+		case "endbfrange":
+			for i := 0; i+2 < len(operands); i += 3 {
+				lo, ok1 := operands[i].(pdfString)
+				hi, ok2 := operands[i+1].(pdfString)
+				if !ok1 || !ok2 {
+					continue
+				}
+				start, end := codeOf(lo), codeOf(hi)
+				if end < start || end-start > 0xffff {
+					continue
+				}
+				switch dst := operands[i+2].(type) {
+				case pdfString:
+					units := utf16Units(dst)
+					if len(units) == 0 {
+						continue
+					}
+					for code := start; code <= end; code++ {
+						shifted := append([]uint16{}, units...)
+						shifted[len(shifted)-1] += uint16(code - start)
+						m[code] = string(utf16.Decode(shifted))
+					}
+				case []any:
+					for j, v := range dst {
+						if s, ok := v.(pdfString); ok && start+uint32(j) <= end {
+							m[start+uint32(j)] = utf16BE(s)
+						}
+					}
+				}
+			}
+		}
+		operands = operands[:0]
+	}
+}
+
+func codeOf(b pdfString) uint32 {
+	var code uint32
+	for i := 0; i < len(b) && i < 4; i++ {
+		code = code<<8 | uint32(b[i])
+	}
+	return code
+}
+
+func utf16Units(b pdfString) []uint16 {
+	units := make([]uint16, len(b)/2)
+	for i := range units {
+		units[i] = uint16(b[2*i])<<8 | uint16(b[2*i+1])
+	}
+	return units
+}
+
+func utf16BE(b pdfString) string {
+	return string(utf16.Decode(utf16Units(b)))
+}
+
+// winAnsiHigh maps the WinAnsi characters 0x80-0x9f that differ from Latin-1
+var winAnsiHigh = map[byte]rune{
+	0x80: '€', 0x85: '…', 0x91: '‘', 0x92: '’', 0x93: '“', 0x94: '”', 0x95: '•', 0x96: '–', 0x97: '—', 0x99: '™',
+}
+
+// decode returns the text of a string shown in the font
+func (f *pdfFont) decode(s pdfString) string {
+	if f != nil && len(f.toUnicode) > 0 {
+		var b strings.Builder
+		for i := 0; i+f.codeLen <= len(s); i += f.codeLen {
+			b.WriteString(f.toUnicode[codeOf(s[i:i+f.codeLen])])
+		}
+		return b.String()
+	}
+	if f != nil && f.codeLen == 2 {
+		return "" // Glyph IDs without a ToUnicode map have no known text
+	}
+	if strings.HasPrefix(string(s), "\xfe\xff") {
+		return utf16BE(s[2:])
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if r, ok := winAnsiHigh[s[i]]; ok {
+			b.WriteRune(r)
+		} else if s[i] >= 0x20 || s[i] == '\t' || s[i] == '\n' {
+			b.WriteRune(rune(s[i]))
+		}
+	}
+	return b.String()
+}
+
+// pageImage is an image on a page, as a file OCR can read
+type pageImage struct {
+	data      []byte
+	mediaType string
+}
+
+// pageText follows the text operators of a content stream and collects the
+// JPEG images it draws, including in form XObjects
+func (d *pdfDoc) pageText(content []byte, resources pdfDict, out *strings.Builder, images *[]pageImage, depth int) {
+	if depth > 4 {
+		return
+	}
+	fonts := map[pdfName]*pdfFont{}
+	fontResources := d.dict(resources["Font"])
+	xobjects := d.dict(resources["XObject"])
+
+	var font *pdfFont
+	var lastY float64
+	last := func() byte {
+		if out.Len() == 0 {
+			return '\n'
+		}
+		return out.String()[out.Len()-1]
+	}
+	newline := func() {
+		if last() != '\n' {
+			out.WriteByte('\n')
+		}
+	}
+	space := func() {
+		if c := last(); c != ' ' && c != '\n' {
+			out.WriteByte(' ')
+		}
+	}
+	number := func(v any) float64 {
+		n, _ := v.(float64)
+		return n
+	}
+
+	l := &pdfLexer{data: content}
+	var operands []any
+	for {
+		tok, err := l.object()
+		if err != nil {
+			return
+		}
+		op, ok := tok.(pdfKeyword)
+		if !ok {
+			operands = append(operands, tok)
+			continue
+		}
+		switch op {
+		case "BI":
+			// Inline image data is binary; skip to its end
+			if i := bytes.Index(content[l.pos:], []byte("EI")); i >= 0 {
+				l.pos += i + 2
+			} else {
+				return
+			}
+		case "Tf":
+			if len(operands) >= 1 {
+				if name, ok := operands[0].(pdfName); ok {
+					if _, cached := fonts[name]; !cached {
+						fonts[name] = d.font(fontResources[name])
+					}
+					font = fonts[name]
+				}
+			}
+		case "Tj":
+			if len(operands) >= 1 {
+				if s, ok := operands[0].(pdfString); ok {
+					out.WriteString(font.decode(s))
+				}
+			}
+		case "'", "\"":
+			newline()
+			if len(operands) >= 1 {
+				if s, ok := operands[len(operands)-1].(pdfString); ok {
+					out.WriteString(font.decode(s))
+				}
+			}
+		case "TJ":
+			if len(operands) >= 1 {
+				if arr, ok := operands[0].([]any); ok {
+					for _, v := range arr {
+						switch v := v.(type) {
+						case pdfString:
+							out.WriteString(font.decode(v))
+						case float64:
+							if v < -250 { // A gap wider than a quarter em separates words
+								space()
+							}
+						}
+					}
+				}
+			}
+		case "T*":
+			newline()
+		case "Td", "TD":
+			if len(operands) >= 2 && number(operands[1]) != 0 {
+				newline()
+			} else {
+				space()
+			}
+		case "Tm":
+			if len(operands) >= 6 {
+				if y := number(operands[5]); y != lastY {
+					newline()
+					lastY = y
+				} else {
+					space()
+				}
+			}
+		case "ET":
+			space()
+		case "Do":
+			if len(operands) >= 1 {
+				if name, ok := operands[0].(pdfName); ok {
+					d.drawXObject(xobjects[name], resources, out, images, depth)
+				}
+			}
+		}
+		operands = operands[:0]
+	}
+}
+
+// drawXObject reads the text of a form or keeps a JPEG image
+func (d *pdfDoc) drawXObject(v any, resources pdfDict, out *strings.Builder, images *[]pageImage, depth int) {
+	s, ok := d.resolve(v).(*pdfStream)
+	if !ok {
+		return
+	}
+	switch s.dict["Subtype"] {
+	case pdfName("Image"):
+		if data, filter, err := d.decodeStream(s); err == nil && filter == "DCTDecode" {
+			*images = append(*images, pageImage{data: data, mediaType: "image/jpeg"})
+		}
+	case pdfName("Form"):
+		data, filter, err := d.decodeStream(s)
+		if err != nil || filter != "" {
+			return
+		}
+		if r := d.dict(s.dict["Resources"]); r != nil {
+			resources = r
+		}
+		d.pageText(data, resources, out, images, depth+1)
+	}
+}
+
+// extractPDF returns the text of each page and the images of pages whose
+// text is too short to be their content
+func extractPDF(data []byte) ([]page, error) {
+	doc, err := parsePDF(data)
+	if err != nil {
+		return nil, err
+	}
+	pdfPages := doc.pages()
+	if len(pdfPages) == 0 {
+		return nil, errors.New("no pages found in the PDF")
+	}
+	pages := make([]page, 0, len(pdfPages))
+	for i, p := range pdfPages {
+		var text strings.Builder
+		var images []pageImage
+		doc.pageText(doc.pageContent(p), p.resources, &text, &images, 0)
+		pg := page{number: i + 1, text: cleanText(text.String())}
+		if len([]rune(pg.text)) < minPageText {
+			pg.images = images
+		}
+		pages = append(pages, pg)
+	}
+	return pages, nil
+}
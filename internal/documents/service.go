@@ -0,0 +1,413 @@
+// Package documents ingests files for retrieval-augmented generation. Files
+// posted to /v1/documents have their text extracted (PDF text, with images
+// of scanned pages and image files transcribed by a vision model), are split
+// into overlapping chunks and embedded with the documents embedder into the
+// tenant's document store. Chat requests reference documents by id or
+// collection in their documents field; the chunks most similar to the last
+// user message replace the references as grounding documents, which the
+// gateway sends to the provider and cites in the response.
+package documents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// Errors returned by the service. HTTP handlers map these to status codes.
+var (
+	ErrNotFound        = errors.New("document not found")
+	ErrNotReady        = errors.New("document is not ready")
+	ErrTooLarge        = errors.New("document exceeds maximum size")
+	ErrUnsupportedType = errors.New("document type not supported")
+	ErrOCRDisabled     = errors.New("images need an OCR model, set [documents] ocr_model")
+	ErrInvalidDocument = errors.New("invalid document")
+	ErrNoQuestion      = errors.New("documents are retrieved for the last user message, which has no text")
+)
+
+// Store is the persistence used by the service (implemented by postgres.TenantStore)
+type Store interface {
+	CreateStoredDocument(ctx context.Context, d *domain.StoredDocument) error
+	UpdateStoredDocument(ctx context.Context, d *domain.StoredDocument) error
+	GetStoredDocument(ctx context.Context, id string) (*domain.StoredDocument, error)
+	ListStoredDocuments(ctx context.Context, apiKeyID, collection string, limit int) ([]*domain.StoredDocument, error)
+	DeleteStoredDocument(ctx context.Context, id string) (bool, error)
+	SaveDocumentChunks(ctx context.Context, documentID string, chunks []domain.DocumentChunk, embeddings [][]float32) error
+	ListUnembeddedDocumentChunks(ctx context.Context, limit int) ([]*domain.DocumentChunk, error)
+	SetDocumentChunkEmbedding(ctx context.Context, id string, embedding []float32) error
+	SearchDocumentChunks(ctx context.Context, embedding []float32, search domain.DocumentSearch) ([]*domain.DocumentChunkMatch, error)
+}
+
+// Embedder embeds texts
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// OCR transcribes the text of an image with a vision model (implemented by
+// gateway.Service). Usage is recorded against apiKeyID.
+type OCR interface {
+	Transcribe(ctx context.Context, model string, image []byte, mediaType, apiKeyID string) (string, error)
+}
+
+const (
+	// embedBatch is how many chunks are embedded per call
+	embedBatch = 32
+
+	// maxProcessing bounds the documents processed at once
+	maxProcessing = 4
+
+	// maxCollectionLen matches the collection column
+	maxCollectionLen = 255
+
+	// DefaultCollection is the collection of documents uploaded without one
+	DefaultCollection = "default"
+)
+
+// IngestRequest describes an uploaded document
+type IngestRequest struct {
+	Filename   string
+	MimeType   string // Detected from the file name and content when empty
+	Collection string
+	APIKeyID   string
+}
+
+// Service ingests documents and retrieves their chunks for chat requests
+type Service struct {
+	cfg      config.DocumentsConfig
+	store    Store
+	embedder Embedder
+	ocr      OCR
+
+	processing chan struct{} // Slots for documents being processed
+	indexMu    sync.Mutex    // One reindexing pass at a time
+}
+
+// NewService creates a new document service
+func NewService(cfg config.DocumentsConfig, store Store, embedder Embedder) *Service {
+	return &Service{
+		cfg:        cfg,
+		store:      store,
+		embedder:   embedder,
+		processing: make(chan struct{}, maxProcessing),
+	}
+}
+
+// SetOCR sets the transcriber of images, used when ocr_model is set
+func (s *Service) SetOCR(ocr OCR) {
+	s.ocr = ocr
+}
+
+// ocrEnabled reports whether images can be transcribed
+func (s *Service) ocrEnabled() bool {
+	return s.ocr != nil && s.cfg.OCRModel != ""
+}
+
+// Ingest stores a document and processes it in the background: its status
+// is processing until its chunks are embedded, then ready or failed
+func (s *Service) Ingest(ctx context.Context, req IngestRequest, content io.Reader) (*domain.StoredDocument, error) {
+	data, err := io.ReadAll(io.LimitReader(content, s.cfg.MaxFileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("read document: %w", err)
+	}
+	if int64(len(data)) > s.cfg.MaxFileSize {
+		return nil, fmt.Errorf("%w of %d bytes", ErrTooLarge, s.cfg.MaxFileSize)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w: the file is empty", ErrInvalidDocument)
+	}
+	collection := strings.TrimSpace(req.Collection)
+	if collection == "" {
+		collection = DefaultCollection
+	}
+	if len(collection) > maxCollectionLen {
+		return nil, fmt.Errorf("%w: collection is longer than %d characters", ErrInvalidDocument, maxCollectionLen)
+	}
+
+	mediaType := detectType(req.Filename, req.MimeType, data)
+	switch kindOf(mediaType) {
+	case kindUnsupported:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, mediaType)
+	case kindImage:
+		if !s.ocrEnabled() {
+			return nil, ErrOCRDisabled
+		}
+	}
+
+	filename := req.Filename
+	if filename == "" {
+		filename = "document"
+	}
+	doc := &domain.StoredDocument{
+		Collection: collection,
+		Filename:   filename,
+		MimeType:   mediaType,
+		Bytes:      int64(len(data)),
+		Status:     domain.DocumentStatusProcessing,
+		APIKeyID:   req.APIKeyID,
+	}
+	if err := s.store.CreateStoredDocument(ctx, doc); err != nil {
+		return nil, err
+	}
+
+	processed := *doc
+	go s.process(context.WithoutCancel(ctx), &processed, data)
+	return doc, nil
+}
+
+// process extracts, chunks and embeds a document and records the outcome
+func (s *Service) process(ctx context.Context, doc *domain.StoredDocument, data []byte) {
+	s.processing <- struct{}{}
+	defer func() { <-s.processing }()
+
+	if err := s.index(ctx, doc, data); err != nil {
+		doc.Status = domain.DocumentStatusFailed
+		doc.Error = err.Error()
+		slog.Warn("Failed to process document", "document_id", doc.ID, "filename", doc.Filename, "error", err)
+	} else {
+		doc.Status = domain.DocumentStatusReady
+		slog.Info("Processed document", "document_id", doc.ID, "pages", doc.Pages, "ocr_pages", doc.OCRPages, "chunks", doc.Chunks)
+	}
+	if err := s.store.UpdateStoredDocument(ctx, doc); err != nil {
+		slog.Error("Failed to save document status", "document_id", doc.ID, "error", err)
+	}
+}
+
+func (s *Service) index(ctx context.Context, doc *domain.StoredDocument, data []byte) error {
+	pages, err := extract(data, doc.MimeType)
+	if err != nil {
+		return err
+	}
+
+	skippedImages := false
+	for i := range pages {
+		for _, img := range pages[i].images {
+			if !s.ocrEnabled() || doc.OCRPages >= s.cfg.MaxOCRPages {
+				skippedImages = true
+				break
+			}
+			text, err := s.ocr.Transcribe(ctx, s.cfg.OCRModel, img.data, img.mediaType, doc.APIKeyID)
+			if err != nil {
+				return fmt.Errorf("OCR: %w", err)
+			}
+			doc.OCRPages++
+			pages[i].text = strings.TrimSpace(pages[i].text + "\n\n" + cleanText(text))
+		}
+		if pages[i].number > 0 {
+			doc.Pages++
+		}
+	}
+
+	var chunks []domain.DocumentChunk
+	for _, p := range pages {
+		for _, text := range chunkText(p.text, s.cfg.ChunkSize, s.cfg.ChunkOverlap) {
+			chunks = append(chunks, domain.DocumentChunk{Index: len(chunks), Page: p.number, Text: text})
+		}
+	}
+	if len(chunks) == 0 {
+		if skippedImages && !s.ocrEnabled() {
+			return errors.New("no text found; scanned documents need [documents] ocr_model")
+		}
+		return errors.New("no text found in the document")
+	}
+
+	embeddings := make([][]float32, 0, len(chunks))
+	for start := 0; start < len(chunks); start += embedBatch {
+		batch := chunks[start:min(start+embedBatch, len(chunks))]
+		texts := make([]string, len(batch))
+		for i, c := range batch {
+			texts[i] = c.Text
+		}
+		vectors, err := s.embedder.Embed(ctx, texts)
+		if err != nil {
+			return fmt.Errorf("embed chunks: %w", err)
+		}
+		if len(vectors) != len(batch) {
+			return fmt.Errorf("embedder returned %d vectors for %d chunks", len(vectors), len(batch))
+		}
+		embeddings = append(embeddings, vectors...)
+	}
+	if err := s.store.SaveDocumentChunks(ctx, doc.ID, chunks, embeddings); err != nil {
+		return fmt.Errorf("save chunks: %w", err)
+	}
+	doc.Chunks = len(chunks)
+	return nil
+}
+
+// Get returns a document
+func (s *Service) Get(ctx context.Context, id string) (*domain.StoredDocument, error) {
+	if uuid.Validate(id) != nil {
+		return nil, ErrNotFound
+	}
+	doc, err := s.store.GetStoredDocument(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return nil, ErrNotFound
+	}
+	return doc, nil
+}
+
+// List returns the newest documents visible to an API key, optionally of one
+// collection
+func (s *Service) List(ctx context.Context, apiKeyID, collection string, limit int) ([]*domain.StoredDocument, error) {
+	return s.store.ListStoredDocuments(ctx, apiKeyID, collection, limit)
+}
+
+// Delete removes a document and its chunks
+func (s *Service) Delete(ctx context.Context, id string) error {
+	ok, err := s.store.DeleteStoredDocument(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Reindex embeds the chunks without a vector, after the documents embedder
+// changed, and returns how many it embedded
+func (s *Service) Reindex(ctx context.Context) (int, error) {
+	s.indexMu.Lock()
+	defer s.indexMu.Unlock()
+
+	indexed := 0
+	for {
+		batch, err := s.store.ListUnembeddedDocumentChunks(ctx, embedBatch)
+		if err != nil {
+			return indexed, fmt.Errorf("list document chunks: %w", err)
+		}
+		if len(batch) == 0 {
+			return indexed, nil
+		}
+		texts := make([]string, len(batch))
+		for i, c := range batch {
+			texts[i] = c.Text
+		}
+		vectors, err := s.embedder.Embed(ctx, texts)
+		if err != nil {
+			return indexed, fmt.Errorf("embed document chunks: %w", err)
+		}
+		if len(vectors) != len(batch) {
+			return indexed, fmt.Errorf("embedder returned %d vectors for %d chunks", len(vectors), len(batch))
+		}
+		for i, c := range batch {
+			if err := s.store.SetDocumentChunkEmbedding(ctx, c.ID, vectors[i]); err != nil {
+				return indexed, fmt.Errorf("store document chunk embedding: %w", err)
+			}
+			indexed++
+		}
+	}
+}
+
+// CanAccess reports whether a document uploaded by an API key is being used
+// by the same key. Admin uploads and session (admin) callers see every document.
+func CanAccess(doc *domain.StoredDocument, apiKeyID string) bool {
+	return doc.APIKeyID == "" || apiKeyID == "" || doc.APIKeyID == apiKeyID
+}
+
+// Resolve replaces the request's document references, by id or collection,
+// with the stored chunks most similar to its last user message, and returns
+// the chunks retrieved. Documents carrying their text are kept as they are.
+func (s *Service) Resolve(ctx context.Context, req *domain.ChatRequest, apiKeyID string) ([]*domain.DocumentChunkMatch, error) {
+	search := domain.DocumentSearch{APIKeyID: apiKeyID, Limit: s.cfg.TopK}
+	var kept []domain.Document
+	for _, d := range req.Documents {
+		switch {
+		case !d.Reference():
+			kept = append(kept, d)
+		case d.ID != "":
+			doc, err := s.Get(ctx, d.ID)
+			if err == nil && !CanAccess(doc, apiKeyID) {
+				err = ErrNotFound
+			}
+			if errors.Is(err, ErrNotFound) {
+				return nil, fmt.Errorf("%w: %s", ErrNotFound, d.ID)
+			}
+			if err != nil {
+				return nil, err
+			}
+			if doc.Status != domain.DocumentStatusReady {
+				return nil, fmt.Errorf("%w: %s is %s", ErrNotReady, d.ID, doc.Status)
+			}
+			search.DocumentIDs = append(search.DocumentIDs, d.ID)
+		default:
+			search.Collections = append(search.Collections, d.Collection)
+		}
+	}
+	if len(search.DocumentIDs) == 0 && len(search.Collections) == 0 {
+		return nil, nil
+	}
+
+	question := lastUserText(req.Messages)
+	if question == "" {
+		return nil, ErrNoQuestion
+	}
+	vectors, err := s.embedder.Embed(ctx, []string{question})
+	if err != nil {
+		return nil, fmt.Errorf("embed question: %w", err)
+	}
+	if len(vectors) != 1 {
+		return nil, fmt.Errorf("embedder returned %d vectors for the question", len(vectors))
+	}
+	matches, err := s.store.SearchDocumentChunks(ctx, vectors[0], search)
+	if err != nil {
+		return nil, err
+	}
+
+	var retrieved []*domain.DocumentChunkMatch
+	for _, m := range matches {
+		if m.Score >= s.cfg.MinScore {
+			retrieved = append(retrieved, m)
+			kept = append(kept, ChunkDocument(m))
+		}
+	}
+	req.Documents = kept
+	return retrieved, nil
+}
+
+// ChunkDocument returns the grounding document of a retrieved chunk. Its ID
+// is "<document id>#<chunk index>", which citations refer to.
+func ChunkDocument(m *domain.DocumentChunkMatch) domain.Document {
+	props := map[string]string{
+		"title":       m.Filename,
+		"document_id": m.Chunk.DocumentID,
+		"collection":  m.Collection,
+	}
+	if m.Chunk.Page > 0 {
+		props["page"] = strconv.Itoa(m.Chunk.Page)
+	}
+	return domain.Document{
+		ID:              fmt.Sprintf("%s#%d", m.Chunk.DocumentID, m.Chunk.Index),
+		Text:            m.Chunk.Text,
+		AdditionalProps: props,
+	}
+}
+
+// lastUserText returns the text of the last user message
+func lastUserText(messages []domain.Message) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role != "user" {
+			continue
+		}
+		var parts []string
+		for _, block := range messages[i].Content {
+			if block.Type == "text" && strings.TrimSpace(block.Text) != "" {
+				parts = append(parts, block.Text)
+			}
+		}
+		return strings.TrimSpace(strings.Join(parts, "\n"))
+	}
+	return ""
+}
@@ -0,0 +1,344 @@
+package documents
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+)
+
+type fakeStore struct {
+	mu     sync.Mutex
+	docs   map[string]*domain.StoredDocument
+	chunks map[string][]domain.DocumentChunk
+	vecs   map[string][][]float32
+	nextID int
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{docs: map[string]*domain.StoredDocument{}, chunks: map[string][]domain.DocumentChunk{}, vecs: map[string][][]float32{}}
+}
+
+func (f *fakeStore) CreateStoredDocument(ctx context.Context, d *domain.StoredDocument) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextID++
+	d.ID = fmt.Sprintf("00000000-0000-0000-0000-%012d", f.nextID)
+	d.CreatedAt = time.Now()
+	copied := *d
+	f.docs[d.ID] = &copied
+	return nil
+}
+
+func (f *fakeStore) UpdateStoredDocument(ctx context.Context, d *domain.StoredDocument) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	copied := *d
+	f.docs[d.ID] = &copied
+	return nil
+}
+
+func (f *fakeStore) GetStoredDocument(ctx context.Context, id string) (*domain.StoredDocument, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	d, ok := f.docs[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *d
+	return &copied, nil
+}
+
+func (f *fakeStore) ListStoredDocuments(ctx context.Context, apiKeyID, collection string, limit int) ([]*domain.StoredDocument, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var list []*domain.StoredDocument
+	for _, d := range f.docs {
+		if CanAccess(d, apiKeyID) && (collection == "" || d.Collection == collection) {
+			list = append(list, d)
+		}
+	}
+	return list, nil
+}
+
+func (f *fakeStore) DeleteStoredDocument(ctx context.Context, id string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, ok := f.docs[id]
+	delete(f.docs, id)
+	return ok, nil
+}
+
+func (f *fakeStore) SaveDocumentChunks(ctx context.Context, documentID string, chunks []domain.DocumentChunk, embeddings [][]float32) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range chunks {
+		chunks[i].ID = documentID + "/" + strconv.Itoa(i)
+		chunks[i].DocumentID = documentID
+	}
+	f.chunks[documentID] = chunks
+	f.vecs[documentID] = embeddings
+	return nil
+}
+
+func (f *fakeStore) ListUnembeddedDocumentChunks(ctx context.Context, limit int) ([]*domain.DocumentChunk, error) {
+	return nil, nil
+}
+
+func (f *fakeStore) SetDocumentChunkEmbedding(ctx context.Context, id string, embedding []float32) error {
+	return nil
+}
+
+func (f *fakeStore) SearchDocumentChunks(ctx context.Context, embedding []float32, search domain.DocumentSearch) ([]*domain.DocumentChunkMatch, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var matches []*domain.DocumentChunkMatch
+	for id, chunks := range f.chunks {
+		d := f.docs[id]
+		if d.Status != domain.DocumentStatusReady || !CanAccess(d, search.APIKeyID) {
+			continue
+		}
+		selected := false
+		for _, want := range search.DocumentIDs {
+			selected = selected || want == id
+		}
+		for _, want := range search.Collections {
+			selected = selected || want == d.Collection
+		}
+		if !selected {
+			continue
+		}
+		for i, c := range chunks {
+			matches = append(matches, &domain.DocumentChunkMatch{Chunk: c, Filename: d.Filename, Collection: d.Collection,
+				Score: cosine(embedding, f.vecs[id][i])})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	return matches[:min(len(matches), search.Limit)], nil
+}
+
+// wordEmbedder embeds texts as hashed bags of words, so texts sharing words are similar
+type wordEmbedder struct{}
+
+func (wordEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		v := make([]float32, 64)
+		for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return r < 'a' || r > 'z' }) {
+			h := fnv.New32a()
+			h.Write([]byte(word))
+			v[h.Sum32()%64]++
+		}
+		vectors[i] = v
+	}
+	return vectors, nil
+}
+
+func cosine(a, b []float32) float64 {
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(na*nb)
+}
+
+type fakeOCR struct{ calls int }
+
+func (o *fakeOCR) Transcribe(ctx context.Context, model string, image []byte, mediaType, apiKeyID string) (string, error) {
+	o.calls++
+	return "Scanned invoice total is 420 euros", nil
+}
+
+// buildPDF returns a PDF whose pages show the given content streams, the
+// first stored compressed
+func buildPDF(contents ...string) []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.7\n")
+	kids := make([]string, len(contents))
+	for i := range contents {
+		kids[i] = fmt.Sprintf("%d 0 R", 10+i)
+	}
+	fmt.Fprintf(&b, "1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n")
+	fmt.Fprintf(&b, "2 0 obj\n<< /Type /Pages /Kids [%s] /Count %d /Resources << /Font << /F1 3 0 R >> /XObject << /Im1 4 0 R >> >> >>\nendobj\n",
+		strings.Join(kids, " "), len(contents))
+	fmt.Fprintf(&b, "3 0 obj\n<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>\nendobj\n")
+	jpeg := "\xff\xd8\xff\xe0fake jpeg\xff\xd9"
+	fmt.Fprintf(&b, "4 0 obj\n<< /Type /XObject /Subtype /Image /Filter /DCTDecode /Length %d >>\nstream\n%s\nendstream\nendobj\n", len(jpeg), jpeg)
+	for i, content := range contents {
+		fmt.Fprintf(&b, "%d 0 obj\n<< /Type /Page /Parent 2 0 R /Contents %d 0 R >>\nendobj\n", 10+i, 20+i)
+		data, filter := []byte(content), ""
+		if i == 0 {
+			var z bytes.Buffer
+			w := zlib.NewWriter(&z)
+			w.Write(data)
+			w.Close()
+			data, filter = z.Bytes(), " /Filter /FlateDecode"
+		}
+		fmt.Fprintf(&b, "%d 0 obj\n<< /Length %d%s >>\nstream\n%s\nendstream\nendobj\n", 20+i, len(data), filter, data)
+	}
+	b.WriteString("trailer\n<< /Root 1 0 R >>\n%%EOF\n")
+	return b.Bytes()
+}
+
+func TestExtractPDF(t *testing.T) {
+	pdf := buildPDF(
+		"BT /F1 12 Tf 72 720 Td (Quarterly report) Tj 0 -14 Td [(Revenue gr) 20 (ew by) -300 (12%) ] TJ ET",
+		"BT /F1 12 Tf 72 720 Td (Caf\\351 \\(Paris\\)) Tj T* (Second line) Tj ET",
+		"q 612 0 0 792 0 0 cm /Im1 Do Q",
+	)
+	pages, err := extract(pdf, "application/pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) != 3 {
+		t.Fatalf("expected 3 pages, got %d", len(pages))
+	}
+	if want := "Quarterly report\nRevenue grew by 12%"; pages[0].text != want {
+		t.Errorf("page 1: got %q, want %q", pages[0].text, want)
+	}
+	if want := "Café (Paris)\nSecond line"; pages[1].text != want {
+		t.Errorf("page 2: got %q, want %q", pages[1].text, want)
+	}
+	if pages[2].number != 3 || pages[2].text != "" || len(pages[2].images) != 1 || pages[2].images[0].mediaType != "image/jpeg" {
+		t.Errorf("expected the scanned page to yield its image, got %+v", pages[2])
+	}
+
+	if _, err := extract([]byte("%PDF-1.4\n1 0 obj\n<< /Type /Catalog >>\nendobj\ntrailer\n<< /Root 1 0 R /Encrypt 5 0 R >>"), "application/pdf"); !errors.Is(err, errEncryptedPDF) {
+		t.Errorf("expected errEncryptedPDF, got %v", err)
+	}
+}
+
+func TestToUnicode(t *testing.T) {
+	cmap := []byte(`begincmap 1 begincodespacerange <0000> <FFFF> endcodespacerange
+2 beginbfchar <0003> <0020> <0011> <0048> endbfchar
+1 beginbfrange <0044> <0046> <0061> endbfrange endcmap`)
+	m, codeLen := parseToUnicode(cmap, 1)
+	font := &pdfFont{codeLen: codeLen, toUnicode: m}
+	if got := font.decode(pdfString("\x00\x11\x00\x44\x00\x03\x00\x46")); got != "Ha c" {
+		t.Errorf("got %q, want %q", got, "Ha c")
+	}
+}
+
+func TestChunkText(t *testing.T) {
+	text := strings.Repeat("The quick brown fox jumps over the lazy dog. ", 40)
+	chunks := chunkText(text, 200, 40)
+	if len(chunks) < 10 {
+		t.Fatalf("expected the text to be split, got %d chunks", len(chunks))
+	}
+	for i, c := range chunks {
+		if n := len([]rune(c)); n > 200 {
+			t.Errorf("chunk %d has %d characters", i, n)
+		}
+		if !strings.HasSuffix(c, ".") && i < len(chunks)-1 {
+			t.Errorf("chunk %d doesn't end at a sentence: %q", i, c)
+		}
+	}
+	if got := chunkText("  short  ", 200, 40); len(got) != 1 || got[0] != "short" {
+		t.Errorf("unexpected chunks %q", got)
+	}
+}
+
+// waitProcessed waits for a document to leave the processing state
+func waitProcessed(t *testing.T, s *Service, id string) *domain.StoredDocument {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		doc, err := s.Get(context.Background(), id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if doc.Status != domain.DocumentStatusProcessing {
+			return doc
+		}
+	}
+	t.Fatalf("document %s was not processed", id)
+	return nil
+}
+
+func TestIngestAndResolve(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	cfg := config.Default().Documents
+	cfg.ChunkSize, cfg.ChunkOverlap = 120, 20
+	s := NewService(cfg, store, wordEmbedder{})
+
+	if _, err := s.Ingest(ctx, IngestRequest{Filename: "scan.png"}, strings.NewReader("\x89PNG\r\n\x1a\n")); !errors.Is(err, ErrOCRDisabled) {
+		t.Fatalf("expected ErrOCRDisabled, got %v", err)
+	}
+	if _, err := s.Ingest(ctx, IngestRequest{Filename: "a.zip", MimeType: "application/zip"}, strings.NewReader("PK")); !errors.Is(err, ErrUnsupportedType) {
+		t.Fatalf("expected ErrUnsupportedType, got %v", err)
+	}
+
+	text := "Refund policy. Customers may return items within 30 days of delivery for a full refund.\n\n" +
+		"Shipping. Orders ship within two business days from our warehouse in Lyon."
+	doc, err := s.Ingest(ctx, IngestRequest{Filename: "policy.md", Collection: "support", APIKeyID: "key-1"}, strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Status != domain.DocumentStatusProcessing || doc.MimeType != "text/markdown" {
+		t.Fatalf("unexpected document %+v", doc)
+	}
+	doc = waitProcessed(t, s, doc.ID)
+	if doc.Status != domain.DocumentStatusReady || doc.Chunks != 2 {
+		t.Fatalf("expected a ready document with 2 chunks, got %+v", doc)
+	}
+
+	question := []domain.Message{{Role: "user", Content: []domain.ContentBlock{{Type: "text", Text: "How many days do customers have to return items for a refund?"}}}}
+	req := &domain.ChatRequest{Messages: question, Documents: []domain.Document{
+		{ID: "inline", Text: "Kept as it is"},
+		{Collection: "support"},
+	}}
+	matches, err := s.Resolve(ctx, req, "key-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) == 0 || !strings.Contains(matches[0].Chunk.Text, "30 days") {
+		t.Fatalf("expected the refund chunk first, got %+v", matches)
+	}
+	if req.Documents[0].ID != "inline" || req.Documents[1].ID != doc.ID+"#0" || req.Documents[1].AdditionalProps["title"] != "policy.md" {
+		t.Fatalf("unexpected grounding documents %+v", req.Documents)
+	}
+
+	// Another key's documents can't be referenced
+	req = &domain.ChatRequest{Messages: question, Documents: []domain.Document{{ID: doc.ID}}}
+	if _, err := s.Resolve(ctx, req, "key-2"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for another key, got %v", err)
+	}
+	req = &domain.ChatRequest{Documents: []domain.Document{{ID: doc.ID}}}
+	if _, err := s.Resolve(ctx, req, "key-1"); !errors.Is(err, ErrNoQuestion) {
+		t.Fatalf("expected ErrNoQuestion, got %v", err)
+	}
+
+	// Scanned PDF pages are transcribed when OCR is configured
+	cfg.OCRModel = "gpt-4o-mini"
+	ocr := &fakeOCR{}
+	s = NewService(cfg, store, wordEmbedder{})
+	s.SetOCR(ocr)
+	scan, err := s.Ingest(ctx, IngestRequest{Filename: "invoice.pdf"}, bytes.NewReader(buildPDF("BT ET", "q /Im1 Do Q")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	scan = waitProcessed(t, s, scan.ID)
+	if scan.Status != domain.DocumentStatusReady || scan.Pages != 2 || scan.OCRPages != 1 || ocr.calls != 1 {
+		t.Fatalf("expected one transcribed page, got %+v after %d OCR calls", scan, ocr.calls)
+	}
+	if chunks := store.chunks[scan.ID]; len(chunks) != 1 || chunks[0].Page != 2 {
+		t.Fatalf("expected a chunk of page 2, got %+v", chunks)
+	}
+}
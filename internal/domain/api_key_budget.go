@@ -0,0 +1,33 @@
+package domain
+
+// APIKeyBudget caps what a single API key may spend in the current UTC day
+// and month, on top of its role's and project's budgets. Past a soft limit
+// requests still go through with a warning header and the alert webhook is
+// called once per window; past a hard limit they are refused with
+// budget_exceeded. Zero means no limit.
+type APIKeyBudget struct {
+	DailySoftLimitUSD   float64 `json:"daily_soft_limit_usd,omitempty"`
+	DailyHardLimitUSD   float64 `json:"daily_hard_limit_usd,omitempty"`
+	MonthlySoftLimitUSD float64 `json:"monthly_soft_limit_usd,omitempty"`
+	MonthlyHardLimitUSD float64 `json:"monthly_hard_limit_usd,omitempty"`
+	AlertWebhook        string  `json:"alert_webhook,omitempty"` // Receives soft and hard limit events
+}
+
+// IsZero reports whether the budget sets no limit
+func (b APIKeyBudget) IsZero() bool {
+	return b.DailySoftLimitUSD == 0 && b.DailyHardLimitUSD == 0 &&
+		b.MonthlySoftLimitUSD == 0 && b.MonthlyHardLimitUSD == 0
+}
+
+// APIKeySpend is an API key's cost so far in the current UTC day and month
+type APIKeySpend struct {
+	DailyUSD   float64 `json:"daily_usd"`
+	MonthlyUSD float64 `json:"monthly_usd"`
+}
+
+// APIKeyBudgetStatus is an API key's spend against its budget
+type APIKeyBudgetStatus struct {
+	Spend               APIKeySpend `json:"spend"`
+	DailyRemainingUSD   *float64    `json:"daily_remaining_usd,omitempty"`   // Before the daily hard limit; nil without one
+	MonthlyRemainingUSD *float64    `json:"monthly_remaining_usd,omitempty"` // Before the monthly hard limit; nil without one
+}
@@ -0,0 +1,97 @@
+// Package domain defines Assistants API domain types.
+package domain
+
+import "time"
+
+// Assistant tool types. "mcp" is a ModelGate extension that gives the
+// assistant the MCP tools the caller can see, run by the gateway itself.
+const (
+	AssistantToolFunction = "function"
+	AssistantToolMCP      = "mcp"
+)
+
+// AssistantTool is a tool an assistant may call
+type AssistantTool struct {
+	Type      string              `json:"type"`
+	Function  *FunctionDefinition `json:"function,omitempty"`
+	MCPServer string              `json:"server,omitempty"` // For "mcp": one server's tools instead of all
+}
+
+// Assistant is a stored model, instructions and tool set that runs execute
+type Assistant struct {
+	ID           string            `json:"id"`
+	APIKeyID     string            `json:"api_key_id,omitempty"`
+	Name         string            `json:"name,omitempty"`
+	Description  string            `json:"description,omitempty"`
+	Model        string            `json:"model"`
+	Instructions string            `json:"instructions,omitempty"`
+	Tools        []AssistantTool   `json:"tools"`
+	Temperature  *float32          `json:"temperature,omitempty"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+}
+
+// RunStatus is the lifecycle state of a run (OpenAI-compatible values)
+type RunStatus string
+
+const (
+	RunStatusQueued         RunStatus = "queued"
+	RunStatusInProgress     RunStatus = "in_progress"
+	RunStatusRequiresAction RunStatus = "requires_action"
+	RunStatusCancelling     RunStatus = "cancelling"
+	RunStatusCancelled      RunStatus = "cancelled"
+	RunStatusFailed         RunStatus = "failed"
+	RunStatusCompleted      RunStatus = "completed"
+	RunStatusIncomplete     RunStatus = "incomplete"
+	RunStatusExpired        RunStatus = "expired"
+)
+
+// IsTerminal reports whether the run will not change state again
+func (s RunStatus) IsTerminal() bool {
+	switch s {
+	case RunStatusCancelled, RunStatusFailed, RunStatusCompleted, RunStatusIncomplete, RunStatusExpired:
+		return true
+	}
+	return false
+}
+
+// Run executes an assistant on a thread: the model is called, MCP tools it
+// asks for are run by the gateway, and function calls are handed back to the
+// client through RequiredToolCalls until the model answers.
+type Run struct {
+	ID                string            `json:"id"`
+	ThreadID          string            `json:"thread_id"`
+	AssistantID       string            `json:"assistant_id"`
+	APIKeyID          string            `json:"api_key_id,omitempty"`
+	Status            RunStatus         `json:"status"`
+	Model             string            `json:"model"`
+	Instructions      string            `json:"instructions,omitempty"`
+	Tools             []AssistantTool   `json:"tools"`
+	Temperature       *float32          `json:"temperature,omitempty"`
+	RequiredToolCalls []ToolCall        `json:"required_tool_calls,omitempty"` // Set while requires_action
+	LastError         *RunError         `json:"last_error,omitempty"`
+	Usage             RunUsage          `json:"usage"`
+	Metadata          map[string]string `json:"metadata,omitempty"`
+	CreatedAt         time.Time         `json:"created_at"`
+	StartedAt         *time.Time        `json:"started_at,omitempty"`
+	ExpiresAt         time.Time         `json:"expires_at"` // The run expires if it is still active at this time
+	CompletedAt       *time.Time        `json:"completed_at,omitempty"`
+	FailedAt          *time.Time        `json:"failed_at,omitempty"`
+	CancelledAt       *time.Time        `json:"cancelled_at,omitempty"`
+}
+
+// RunError is why a run failed
+type RunError struct {
+	Code    string `json:"code"` // "server_error", "rate_limit_exceeded" or "invalid_prompt"
+	Message string `json:"message"`
+}
+
+func (e *RunError) Error() string { return e.Message }
+
+// RunUsage totals the tokens of every model call a run made
+type RunUsage struct {
+	PromptTokens     int64 `json:"prompt_tokens"`
+	CompletionTokens int64 `json:"completion_tokens"`
+	TotalTokens      int64 `json:"total_tokens"`
+}
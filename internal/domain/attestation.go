@@ -0,0 +1,26 @@
+// Package domain defines response attestation domain types.
+package domain
+
+import "time"
+
+// AttestationKey is an Ed25519 key signing response attestations. Its
+// private half never leaves the store and the attestation service.
+type AttestationKey struct {
+	ID        string     `json:"id"` // Fingerprint of the public key
+	PublicKey []byte     `json:"public_key"`
+	CreatedBy string     `json:"created_by,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	RetiredAt *time.Time `json:"retired_at,omitempty"` // Set once rotated out
+}
+
+// ResponseAttestation is a signed statement of which response the gateway
+// returned for a request, under which policy version
+type ResponseAttestation struct {
+	RequestID     string    `json:"request_id"`
+	KeyID         string    `json:"key_id"`
+	RequestHash   string    `json:"request_hash"`  // Hex SHA-256 of the request body
+	ResponseHash  string    `json:"response_hash"` // Hex SHA-256 of the response body
+	PolicyVersion string    `json:"policy_version"`
+	SignedAt      time.Time `json:"signed_at"`
+	Signature     []byte    `json:"signature"`
+}
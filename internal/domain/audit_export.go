@@ -0,0 +1,40 @@
+// Package domain defines audit log export domain types.
+package domain
+
+import "time"
+
+// AuditExportStatus is the state of an audit log export job
+type AuditExportStatus string
+
+const (
+	AuditExportStatusPending   AuditExportStatus = "pending"
+	AuditExportStatusRunning   AuditExportStatus = "running"
+	AuditExportStatusCompleted AuditExportStatus = "completed"
+	AuditExportStatusFailed    AuditExportStatus = "failed"
+)
+
+// AuditLogCursor is the position after which the next export page starts.
+// Export pages are ordered oldest first by (timestamp, id).
+type AuditLogCursor struct {
+	Timestamp time.Time
+	ID        string
+}
+
+// AuditExportJob is an asynchronous export of the audit logs matching a filter.
+// The file is written to the configured object store (ObjectKey) or kept in
+// the database and downloaded with DownloadToken until ExpiresAt.
+type AuditExportJob struct {
+	ID            string            `json:"id"`
+	Status        AuditExportStatus `json:"status"`
+	Format        string            `json:"format"` // "csv" or "jsonl"
+	Filter        AuditLogFilter    `json:"filter"`
+	RequestedBy   string            `json:"requested_by,omitempty"`
+	RowCount      int64             `json:"row_count"`
+	SizeBytes     int64             `json:"size_bytes"`
+	ObjectKey     string            `json:"object_key,omitempty"`
+	DownloadToken string            `json:"-"`
+	Error         string            `json:"error,omitempty"`
+	CreatedAt     time.Time         `json:"created_at"`
+	CompletedAt   *time.Time        `json:"completed_at,omitempty"`
+	ExpiresAt     *time.Time        `json:"expires_at,omitempty"`
+}
@@ -0,0 +1,91 @@
+// Package domain defines batch job domain types.
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// BatchStatus represents the lifecycle state of a batch job (OpenAI-compatible values)
+type BatchStatus string
+
+const (
+	BatchStatusValidating BatchStatus = "validating"
+	BatchStatusInProgress BatchStatus = "in_progress"
+	BatchStatusFinalizing BatchStatus = "finalizing"
+	BatchStatusCompleted  BatchStatus = "completed"
+	BatchStatusFailed     BatchStatus = "failed"
+	BatchStatusExpired    BatchStatus = "expired"
+	BatchStatusCancelling BatchStatus = "cancelling"
+	BatchStatusCancelled  BatchStatus = "cancelled"
+)
+
+// IsTerminal reports whether the batch will not change state again
+func (s BatchStatus) IsTerminal() bool {
+	switch s {
+	case BatchStatusCompleted, BatchStatusFailed, BatchStatusExpired, BatchStatusCancelled:
+		return true
+	}
+	return false
+}
+
+// Batch is a ModelGate batch job that is executed by a provider's native batch API
+type Batch struct {
+	ID               string         `json:"id"`
+	APIKeyID         string         `json:"api_key_id,omitempty"`
+	Endpoint         string         `json:"endpoint"`
+	InputFileID      string         `json:"input_file_id"`
+	OutputFileID     string         `json:"output_file_id,omitempty"`
+	CompletionWindow string         `json:"completion_window"`
+	Status           BatchStatus    `json:"status"`
+	Provider         Provider       `json:"provider"`
+	Model            string         `json:"model"`
+	ProviderBatchID  string         `json:"provider_batch_id,omitempty"`
+	RequestCounts    BatchCounts    `json:"request_counts"`
+	Errors           string         `json:"errors,omitempty"`
+	Metadata         map[string]any `json:"metadata,omitempty"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	CompletedAt      *time.Time     `json:"completed_at,omitempty"`
+}
+
+// BatchCounts tracks request progress within a batch
+type BatchCounts struct {
+	Total     int `json:"total"`
+	Completed int `json:"completed"`
+	Failed    int `json:"failed"`
+}
+
+// BatchItem is a single request within a batch
+type BatchItem struct {
+	CustomID string
+	Request  *ChatRequest
+}
+
+// BatchItemResult is the provider's outcome for a single batch item
+type BatchItemResult struct {
+	CustomID   string
+	StatusCode int
+	Response   *ChatResponse
+	Error      string
+}
+
+// ProviderBatchStatus is the provider-side view of a submitted batch
+type ProviderBatchStatus struct {
+	Status        BatchStatus
+	RequestCounts BatchCounts
+}
+
+// BatchCapable is an optional interface for providers with a native, discounted batch API
+type BatchCapable interface {
+	// SubmitBatch submits the items and returns the provider's batch ID
+	SubmitBatch(ctx context.Context, items []BatchItem) (string, error)
+	// GetBatchStatus polls the provider for the batch state
+	GetBatchStatus(ctx context.Context, providerBatchID string) (*ProviderBatchStatus, error)
+	// GetBatchResults downloads per-item results of a finished batch
+	GetBatchResults(ctx context.Context, providerBatchID string) ([]BatchItemResult, error)
+	// CancelBatch requests cancellation of a running batch
+	CancelBatch(ctx context.Context, providerBatchID string) error
+	// BatchDiscount is the price multiplier applied to batch usage (e.g. 0.5 for 50% off)
+	BatchDiscount() float64
+}
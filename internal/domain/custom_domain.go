@@ -0,0 +1,27 @@
+// Package domain defines custom domain types.
+package domain
+
+import "time"
+
+// CertSource is how a custom domain's TLS certificate is obtained
+type CertSource string
+
+const (
+	CertSourceACME     CertSource = "acme"     // Issued and renewed through ACME
+	CertSourceUploaded CertSource = "uploaded" // Uploaded by an admin
+)
+
+// CustomDomain is a hostname the gateway serves for a tenant
+type CustomDomain struct {
+	ID            string     `json:"id"`
+	Hostname      string     `json:"hostname"` // Lowercase, without port
+	TenantSlug    string     `json:"tenant_slug"`
+	CertSource    CertSource `json:"cert_source"`
+	CertPEM       string     `json:"cert_pem,omitempty"`        // Uploaded certificate chain
+	KeyPEM        string     `json:"-"`                         // Uploaded private key
+	CertExpiresAt *time.Time `json:"cert_expires_at,omitempty"` // Expiry of the uploaded certificate
+	Enabled       bool       `json:"enabled"`
+	CreatedBy     string     `json:"created_by,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
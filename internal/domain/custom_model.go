@@ -0,0 +1,55 @@
+package domain
+
+import "time"
+
+// CustomModel is a model served by an OpenAI-compatible endpoint, such as a
+// vLLM or TGI server, that an admin registered under the custom provider.
+// Clients address it as "custom/<ModelID>".
+type CustomModel struct {
+	ID                string    `json:"id"`
+	ModelID           string    `json:"model_id"`
+	Name              string    `json:"name"`
+	BaseURL           string    `json:"base_url"`                 // Up to /v1, e.g. http://vllm:8000/v1
+	UpstreamModel     string    `json:"upstream_model,omitempty"` // Model name sent to the server; defaults to ModelID
+	ContextWindow     int       `json:"context_window"`
+	MaxOutputTokens   int       `json:"max_output_tokens"`
+	InputCostPer1M    float64   `json:"input_cost_per_1m"`
+	OutputCostPer1M   float64   `json:"output_cost_per_1m"`
+	SupportsTools     bool      `json:"supports_tools"`
+	SupportsVision    bool      `json:"supports_vision"`
+	SupportsReasoning bool      `json:"supports_reasoning"`
+	SupportsStreaming bool      `json:"supports_streaming"`
+	Enabled           bool      `json:"enabled"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// Upstream returns the model name the endpoint expects
+func (m *CustomModel) Upstream() string {
+	if m.UpstreamModel != "" {
+		return m.UpstreamModel
+	}
+	return m.ModelID
+}
+
+// ModelInfo describes the model as it is listed in /v1/models
+func (m *CustomModel) ModelInfo() ModelInfo {
+	name := m.Name
+	if name == "" {
+		name = m.ModelID
+	}
+	return ModelInfo{
+		ID:                string(ProviderCustom) + "/" + m.ModelID,
+		Name:              name,
+		Provider:          ProviderCustom,
+		SupportsTools:     m.SupportsTools,
+		SupportsReasoning: m.SupportsReasoning,
+		SupportsVision:    m.SupportsVision,
+		ContextLimit:      uint32(m.ContextWindow),
+		OutputLimit:       uint32(m.MaxOutputTokens),
+		InputCostPer1M:    m.InputCostPer1M,
+		OutputCostPer1M:   m.OutputCostPer1M,
+		Enabled:           m.Enabled,
+		NativeModelID:     m.Upstream(),
+	}
+}
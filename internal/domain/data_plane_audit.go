@@ -0,0 +1,53 @@
+// Package domain defines data-plane audit domain types.
+package domain
+
+import "time"
+
+// DataPlaneAuditSettings controls the audit events written for /v1 requests.
+// Without stored settings data-plane audit is off.
+type DataPlaneAuditSettings struct {
+	Enabled     bool      `json:"enabled"`
+	StoreEvents bool      `json:"store_events"` // Write events to data_plane_audit_events
+	WebhookURL  string    `json:"webhook_url"`  // Batches of events are posted here; empty for none
+	UpdatedBy   string    `json:"updated_by,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// DataPlaneAuditEvent is the compact audit record of one data-plane request
+type DataPlaneAuditEvent struct {
+	ID         string    `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	RequestID  string    `json:"request_id,omitempty"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	StatusCode int       `json:"status_code"`
+	LatencyMs  int64     `json:"latency_ms"`
+
+	// Actor
+	APIKeyID  string `json:"api_key_id,omitempty"`
+	RoleID    string `json:"role_id,omitempty"`
+	ProjectID string `json:"project_id,omitempty"`
+	ClientIP  string `json:"client_ip,omitempty"`
+
+	Model       string `json:"model,omitempty"`        // As requested
+	ServedModel string `json:"served_model,omitempty"` // After routing and context management
+
+	// Policy enforcement
+	PolicyDecision string   `json:"policy_decision,omitempty"` // "allowed" or "blocked"
+	PolicyCode     string   `json:"policy_code,omitempty"`     // Violation code of a blocked request
+	ToolsAllowed   []string `json:"tools_allowed,omitempty"`
+	ToolsRemoved   []string `json:"tools_removed,omitempty"`
+	ParamsClamped  []string `json:"params_clamped,omitempty"` // As name=value
+
+	CacheStatus     CacheStatus     `json:"cache_status,omitempty"`
+	RoutingStrategy RoutingStrategy `json:"routing_strategy,omitempty"` // Set when intelligent routing picked the model
+}
+
+// DataPlaneAuditFilter narrows a listing of data-plane audit events
+type DataPlaneAuditFilter struct {
+	APIKeyID       string
+	Model          string
+	PolicyDecision string
+	From, To       *time.Time
+	Limit          int
+}
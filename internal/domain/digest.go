@@ -0,0 +1,63 @@
+// Package domain defines usage digest domain types.
+package domain
+
+import "time"
+
+// DigestFrequency is how often a user receives usage digest emails
+type DigestFrequency string
+
+const (
+	DigestOff    DigestFrequency = "off"
+	DigestDaily  DigestFrequency = "daily"
+	DigestWeekly DigestFrequency = "weekly"
+)
+
+// Valid reports whether f is a known frequency
+func (f DigestFrequency) Valid() bool {
+	return f == DigestOff || f == DigestDaily || f == DigestWeekly
+}
+
+// DigestSubscription is a user's usage digest preference
+type DigestSubscription struct {
+	UserID     string          `json:"user_id"`
+	Email      string          `json:"email"`
+	Name       string          `json:"name"`
+	Frequency  DigestFrequency `json:"frequency"`
+	RoleID     string          `json:"role_id,omitempty"`      // Empty covers the whole tenant
+	LastSentAt *time.Time      `json:"last_sent_at,omitempty"` // Scheduled time of the last digest sent
+}
+
+// UsageDigest summarizes usage over a digest period, for the tenant or the
+// API keys of one role
+type UsageDigest struct {
+	Frequency      DigestFrequency    `json:"frequency"`
+	PeriodStart    time.Time          `json:"period_start"`
+	PeriodEnd      time.Time          `json:"period_end"`
+	RoleID         string             `json:"role_id,omitempty"`
+	RoleName       string             `json:"role_name,omitempty"`
+	TotalRequests  int64              `json:"total_requests"`
+	FailedRequests int64              `json:"failed_requests"`
+	TotalTokens    int64              `json:"total_tokens"`
+	TotalCostUSD   float64            `json:"total_cost_usd"`
+	TopModels      []ModelUsage       `json:"top_models"`
+	TopAPIKeys     []APIKeyUsageStats `json:"top_api_keys"`
+	Budget         DigestBudget       `json:"budget"`
+}
+
+// ErrorRate returns the share of requests that failed
+func (d *UsageDigest) ErrorRate() float64 {
+	if d.TotalRequests == 0 {
+		return 0
+	}
+	return float64(d.FailedRequests) / float64(d.TotalRequests)
+}
+
+// DigestBudget is the month-to-date spend and how fast it is growing,
+// against the monthly limit of the tenant quota or of the role's budget policy
+type DigestBudget struct {
+	LimitUSD     float64   `json:"limit_usd"` // 0 when there is no monthly limit
+	SpentUSD     float64   `json:"spent_usd"`
+	DailyBurnUSD float64   `json:"daily_burn_usd"` // Average spend per day this month
+	ProjectedUSD float64   `json:"projected_usd"`  // Spend by month end at the current burn rate
+	PeriodEnd    time.Time `json:"period_end"`
+}
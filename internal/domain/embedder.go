@@ -0,0 +1,49 @@
+// Package domain defines embedder settings domain types.
+package domain
+
+import "time"
+
+// EmbedderFeature is a gateway feature that embeds text
+type EmbedderFeature string
+
+const (
+	EmbedderFeatureSemanticCache EmbedderFeature = "semantic_cache"
+	EmbedderFeatureToolSearch    EmbedderFeature = "tool_search"
+	EmbedderFeatureInjection     EmbedderFeature = "injection_detection"
+	EmbedderFeatureDocuments     EmbedderFeature = "documents"
+)
+
+// EmbedderFeatures lists the features with their own embedder
+var EmbedderFeatures = []EmbedderFeature{EmbedderFeatureSemanticCache, EmbedderFeatureToolSearch, EmbedderFeatureInjection,
+	EmbedderFeatureDocuments}
+
+// Valid reports whether f is a known feature
+func (f EmbedderFeature) Valid() bool {
+	for _, known := range EmbedderFeatures {
+		if f == known {
+			return true
+		}
+	}
+	return false
+}
+
+// EmbedderSettings is the embedder a feature uses. Without stored settings a
+// feature uses the [embedder] section of config.toml.
+type EmbedderSettings struct {
+	Feature    EmbedderFeature `json:"feature"`
+	Type       string          `json:"type"` // "ollama" or "openai" (any OpenAI-compatible endpoint)
+	Model      string          `json:"model"`
+	BaseURL    string          `json:"base_url,omitempty"` // Self-hosted endpoint
+	APIKey     string          `json:"-"`
+	Dimensions int             `json:"dimensions"` // Length of the vectors the model returns
+	UpdatedBy  string          `json:"updated_by,omitempty"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+	FromConfig bool            `json:"from_config"` // Not stored: the config.toml default
+}
+
+// SameVectors reports whether two settings produce comparable vectors; when
+// they don't, stored embeddings must be recomputed
+func (e *EmbedderSettings) SameVectors(other *EmbedderSettings) bool {
+	return e.Type == other.Type && e.Model == other.Model && e.BaseURL == other.BaseURL &&
+		(e.Dimensions == 0 || other.Dimensions == 0 || e.Dimensions == other.Dimensions)
+}
@@ -0,0 +1,29 @@
+package domain
+
+// EmbeddingRequest is a request for the embeddings of a batch of texts
+type EmbeddingRequest struct {
+	Model      string
+	Input      []string
+	Dimensions *int32 // Nil leaves the size to the model
+	RequestID  string
+	APIKeyID   string
+	ProjectID  string
+	TenantID   string
+}
+
+// EmbeddingResponse holds one embedding per input, in order
+type EmbeddingResponse struct {
+	Embeddings   [][]float32
+	Model        string // Model that made the embeddings, a fallback when the requested one failed
+	Provider     Provider
+	FallbackFrom string // Requested model, when a fallback served the request
+	PromptTokens int64
+	CostUSD      float64
+}
+
+// EmbeddingMetadata describes an embedding request on its usage record
+type EmbeddingMetadata struct {
+	Inputs       int    `json:"inputs"`
+	Dimensions   int    `json:"dimensions,omitempty"`
+	FallbackFrom string `json:"fallback_from,omitempty"`
+}
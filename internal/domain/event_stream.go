@@ -0,0 +1,38 @@
+// Package domain defines event streaming domain types.
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// StreamEventType is a kind of row published to the event stream
+type StreamEventType string
+
+const (
+	StreamEventUsage           StreamEventType = "usage"            // usage_records
+	StreamEventPolicyViolation StreamEventType = "policy_violation" // policy_violation_events
+	StreamEventAudit           StreamEventType = "audit"            // audit_logs
+)
+
+// StreamEventTypes lists the event types that can be streamed
+var StreamEventTypes = []StreamEventType{StreamEventUsage, StreamEventPolicyViolation, StreamEventAudit}
+
+// Valid reports whether t is a known event type
+func (t StreamEventType) Valid() bool {
+	for _, known := range StreamEventTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// OutboxEvent is a row waiting in the outbox to be published
+type OutboxEvent struct {
+	ID        int64
+	Type      StreamEventType
+	Payload   json.RawMessage // The source row as inserted
+	CreatedAt time.Time
+	Attempts  int
+}
@@ -0,0 +1,148 @@
+// Package domain defines experiment domain types.
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ExperimentStatus is where an experiment is in its lifecycle
+type ExperimentStatus string
+
+const (
+	ExperimentDraft     ExperimentStatus = "draft"
+	ExperimentRunning   ExperimentStatus = "running"
+	ExperimentPaused    ExperimentStatus = "paused"
+	ExperimentCompleted ExperimentStatus = "completed"
+)
+
+// How a variant's system prompt combines with the request's
+const (
+	SystemPromptReplace = "replace"
+	SystemPromptPrepend = "prepend"
+	SystemPromptAppend  = "append"
+)
+
+// Experiment splits chat traffic across variants that change the model or the
+// system prompt, so their latency, cost and feedback can be compared. While
+// it runs, requests of its audience for its model are assigned a variant by
+// weight; requests of a conversation stay on one variant.
+type Experiment struct {
+	ID          string           `json:"id"`
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Status      ExperimentStatus `json:"status"`
+	Model       string           `json:"model,omitempty"` // Requested model taking part; empty for any model
+
+	// Audience: requests of these roles or API keys take part. Both empty
+	// means every request.
+	RoleIDs   []string `json:"role_ids,omitempty"`
+	APIKeyIDs []string `json:"api_key_ids,omitempty"`
+
+	Variants []ExperimentVariant `json:"variants"`
+
+	CreatedBy string     `json:"created_by,omitempty"` // Email of the admin who created it
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	StartedAt *time.Time `json:"started_at,omitempty"` // First started
+	EndedAt   *time.Time `json:"ended_at,omitempty"`   // Completed
+}
+
+// ExperimentVariant is one arm of an experiment. A variant that changes
+// nothing is the control.
+type ExperimentVariant struct {
+	Name             string `json:"name"`
+	Weight           int    `json:"weight"`                       // Share of traffic, relative to the other variants
+	Model            string `json:"model,omitempty"`              // Model to call; empty keeps the requested one
+	SystemPrompt     string `json:"system_prompt,omitempty"`      // Empty keeps the request's
+	SystemPromptMode string `json:"system_prompt_mode,omitempty"` // replace (default), prepend or append
+}
+
+// Validate checks an experiment's definition
+func (e *Experiment) Validate() error {
+	if strings.TrimSpace(e.Name) == "" {
+		return fmt.Errorf("experiment name is required")
+	}
+	switch e.Status {
+	case ExperimentDraft, ExperimentRunning, ExperimentPaused, ExperimentCompleted:
+	default:
+		return fmt.Errorf("invalid experiment status %q", e.Status)
+	}
+	if len(e.Variants) < 2 {
+		return fmt.Errorf("an experiment needs at least two variants")
+	}
+	names := make(map[string]bool, len(e.Variants))
+	for _, v := range e.Variants {
+		if strings.TrimSpace(v.Name) == "" {
+			return fmt.Errorf("variant name is required")
+		}
+		if names[v.Name] {
+			return fmt.Errorf("duplicate variant %q", v.Name)
+		}
+		names[v.Name] = true
+		if v.Weight <= 0 {
+			return fmt.Errorf("variant %q: weight must be positive", v.Name)
+		}
+		switch v.SystemPromptMode {
+		case "", SystemPromptReplace, SystemPromptPrepend, SystemPromptAppend:
+		default:
+			return fmt.Errorf("variant %q: invalid system prompt mode %q", v.Name, v.SystemPromptMode)
+		}
+	}
+	return nil
+}
+
+// Variant returns the variant with a name, or nil
+func (e *Experiment) Variant(name string) *ExperimentVariant {
+	for i := range e.Variants {
+		if e.Variants[i].Name == name {
+			return &e.Variants[i]
+		}
+	}
+	return nil
+}
+
+// ExperimentAssignment is the experiment variant a request was assigned
+type ExperimentAssignment struct {
+	ExperimentID string `json:"experiment_id"`
+	Variant      string `json:"variant"`
+	Model        string `json:"model,omitempty"` // Set when the variant chose the model
+}
+
+// PinsModel reports whether the variant chose the model, which routing then
+// leaves alone
+func (a *ExperimentAssignment) PinsModel() bool {
+	return a != nil && a.Model != ""
+}
+
+// ExperimentResults compares an experiment's variants over a time range
+type ExperimentResults struct {
+	Experiment *Experiment              `json:"experiment"`
+	From       time.Time                `json:"from"`
+	To         time.Time                `json:"to"`
+	Variants   []ExperimentVariantStats `json:"variants"`
+}
+
+// ExperimentVariantStats is how a variant performed, from its usage records
+type ExperimentVariantStats struct {
+	Variant         string  `json:"variant"`
+	Weight          int     `json:"weight"`
+	Requests        int64   `json:"requests"`
+	Share           float64 `json:"share"` // Of the experiment's requests
+	SuccessRate     float64 `json:"success_rate"`
+	AvgLatencyMs    float64 `json:"avg_latency_ms"`
+	P50LatencyMs    float64 `json:"p50_latency_ms"`
+	P95LatencyMs    float64 `json:"p95_latency_ms"`
+	AvgTTFTMs       float64 `json:"avg_ttft_ms"` // Streamed requests only
+	AvgInputTokens  float64 `json:"avg_input_tokens"`
+	AvgOutputTokens float64 `json:"avg_output_tokens"`
+	TotalCostUSD    float64 `json:"total_cost_usd"`
+	CostPerRequest  float64 `json:"cost_per_request"`
+
+	// Feedback on the variant's responses
+	Feedback   int64   `json:"feedback"`
+	ThumbsUp   int64   `json:"thumbs_up"`
+	ThumbsDown int64   `json:"thumbs_down"`
+	AvgRating  float64 `json:"avg_rating"` // Of the rated responses
+}
@@ -0,0 +1,85 @@
+// Package domain defines feature flag domain types.
+package domain
+
+import "time"
+
+// Feature flags gating gateway behaviors that are rolled out gradually
+const (
+	FeatureRateLimitHeaders = "rate_limit_headers" // X-RateLimit-* headers on /v1 responses
+	FeatureToolCatalog      = "tool_catalog"       // GET /v1/tools
+)
+
+// FeatureFlagDefinition describes a flag the gateway checks and its state when never set
+type FeatureFlagDefinition struct {
+	Key            string
+	Description    string
+	DefaultEnabled bool
+}
+
+// FeatureFlags lists every flag the gateway checks. Only these flags can be set.
+var FeatureFlags = []FeatureFlagDefinition{
+	{Key: FeatureRateLimitHeaders, Description: "Send X-RateLimit-* and burst credit headers on /v1 responses", DefaultEnabled: true},
+	{Key: FeatureToolCatalog, Description: "Serve the caller's allowed tool catalog at GET /v1/tools", DefaultEnabled: true},
+}
+
+// FeatureFlagDefinitionFor returns the definition of a flag, or false if the gateway does not know it
+func FeatureFlagDefinitionFor(key string) (FeatureFlagDefinition, bool) {
+	for _, def := range FeatureFlags {
+		if def.Key == key {
+			return def, true
+		}
+	}
+	return FeatureFlagDefinition{}, false
+}
+
+// FeatureFlag is the state of a flag: a tenant-wide value plus per-role overrides
+type FeatureFlag struct {
+	Key            string          `json:"key"`
+	Description    string          `json:"description"`
+	Enabled        bool            `json:"enabled"`
+	DefaultEnabled bool            `json:"default_enabled"`
+	RoleOverrides  map[string]bool `json:"role_overrides,omitempty"` // role ID -> enabled
+	UpdatedBy      string          `json:"updated_by,omitempty"`
+	UpdatedAt      *time.Time      `json:"updated_at,omitempty"` // nil if the flag was never set
+}
+
+// EnabledFor returns the flag state for a caller with the given roles. A role
+// override takes precedence over the tenant-wide value; if the roles disagree,
+// the flag is enabled.
+func (f *FeatureFlag) EnabledFor(roleIDs ...string) bool {
+	overridden := false
+	for _, id := range roleIDs {
+		if enabled, ok := f.RoleOverrides[id]; ok {
+			if enabled {
+				return true
+			}
+			overridden = true
+		}
+	}
+	if overridden {
+		return false
+	}
+	return f.Enabled
+}
+
+// FeatureFlagChange is a changelog entry recording when a flag was switched
+type FeatureFlagChange struct {
+	ID              string    `json:"id"`
+	FlagKey         string    `json:"flag_key"`
+	RoleID          string    `json:"role_id,omitempty"` // Empty for the tenant-wide value
+	RoleName        string    `json:"role_name,omitempty"`
+	Enabled         *bool     `json:"enabled"` // nil when a role override was cleared
+	PreviousEnabled *bool     `json:"previous_enabled"`
+	Note            string    `json:"note,omitempty"`
+	ActorID         string    `json:"actor_id,omitempty"`
+	ActorEmail      string    `json:"actor_email,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// FeatureFlagChangeFilter selects changelog entries
+type FeatureFlagChangeFilter struct {
+	FlagKey   string
+	StartTime *time.Time
+	EndTime   *time.Time
+	Limit     int
+}
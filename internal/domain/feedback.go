@@ -0,0 +1,76 @@
+// Package domain defines response feedback domain types.
+package domain
+
+import (
+	"fmt"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxFeedbackComment is the longest feedback comment, in characters
+const MaxFeedbackComment = 4000
+
+// Feedback is what a client or reviewer thought of a response: thumbs up or
+// down, a rating from 1 to 5 and a comment, any of them optional. A response
+// has at most one feedback, linked to its usage record; sending feedback
+// again replaces it.
+type Feedback struct {
+	ID            string    `json:"id"`
+	RequestID     string    `json:"request_id"`
+	UsageRecordID string    `json:"usage_record_id"`
+	Model         string    `json:"model"`
+	Provider      Provider  `json:"provider"`
+	RoleID        string    `json:"role_id,omitempty"`    // Role of the request's API key
+	APIKeyID      string    `json:"api_key_id,omitempty"` // Key that made the request
+	ThumbsUp      *bool     `json:"thumbs_up,omitempty"`
+	Rating        *int      `json:"rating,omitempty"`
+	Comment       string    `json:"comment,omitempty"`
+	SubmittedBy   string    `json:"submitted_by,omitempty"` // Email of the reviewer; empty when the API key sent it
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// Validate checks that feedback says something and is within bounds
+func (f *Feedback) Validate() error {
+	if f.ThumbsUp == nil && f.Rating == nil && f.Comment == "" {
+		return fmt.Errorf("feedback needs thumbs, a rating or a comment")
+	}
+	if f.Rating != nil && (*f.Rating < 1 || *f.Rating > 5) {
+		return fmt.Errorf("rating must be between 1 and 5")
+	}
+	if utf8.RuneCountInString(f.Comment) > MaxFeedbackComment {
+		return fmt.Errorf("comment is longer than %d characters", MaxFeedbackComment)
+	}
+	return nil
+}
+
+// Positive reports whether the feedback approves of the response: thumbs up,
+// or a rating of at least minRating without thumbs down
+func (f *Feedback) Positive(minRating int) bool {
+	if f.ThumbsUp != nil {
+		return *f.ThumbsUp
+	}
+	return f.Rating != nil && *f.Rating >= minRating
+}
+
+// FeedbackFilter selects feedback. Zero values don't filter.
+type FeedbackFilter struct {
+	From     time.Time
+	To       time.Time
+	Model    string
+	RoleID   string
+	ThumbsUp *bool
+	Limit    int
+}
+
+// FeedbackStats sums up the feedback on the responses of a model or role
+type FeedbackStats struct {
+	Key        string  `json:"key"`  // Model ID or role ID
+	Name       string  `json:"name"` // Model ID or role name
+	Count      int64   `json:"count"`
+	ThumbsUp   int64   `json:"thumbs_up"`
+	ThumbsDown int64   `json:"thumbs_down"`
+	Rated      int64   `json:"rated"`
+	AvgRating  float64 `json:"avg_rating"` // Of the rated responses
+	Comments   int64   `json:"comments"`
+}
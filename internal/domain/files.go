@@ -0,0 +1,89 @@
+// Package domain defines file and upload domain types.
+package domain
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// UploadStatus represents the lifecycle state of a multipart upload
+type UploadStatus string
+
+const (
+	UploadStatusPending   UploadStatus = "pending"
+	UploadStatusCompleted UploadStatus = "completed"
+	UploadStatusCancelled UploadStatus = "cancelled"
+	UploadStatusExpired   UploadStatus = "expired"
+)
+
+// File statuses
+const (
+	FileStatusProcessed = "processed" // Stitched and ready for use
+	FileStatusDeleted   = "deleted"   // Deleted by its owner or by retention; content is gone
+)
+
+// Where a file's content is kept
+const (
+	FileStorageLocal       = "local"        // StoragePath on the gateway's disk
+	FileStorageObjectStore = "object_store" // StoragePath is the key in the [files] object store
+	FileStorageProvider    = "provider"     // Only with the provider, as ProviderFileID
+)
+
+// Upload is a resumable upload session. The client declares the total size up
+// front, sends numbered parts in any order, then completes the upload.
+type Upload struct {
+	ID          string       `json:"id"`
+	APIKeyID    string       `json:"api_key_id,omitempty"`
+	Filename    string       `json:"filename"`
+	Purpose     string       `json:"purpose"`
+	MimeType    string       `json:"mime_type,omitempty"`
+	Bytes       int64        `json:"bytes"`
+	SHA256      string       `json:"sha256,omitempty"` // Optional expected checksum of the whole file
+	Status      UploadStatus `json:"status"`
+	FileID      string       `json:"file_id,omitempty"`
+	ExpiresAt   time.Time    `json:"expires_at"`
+	CompletedAt *time.Time   `json:"completed_at,omitempty"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+// UploadPart is a single received chunk of an upload
+type UploadPart struct {
+	UploadID    string    `json:"upload_id"`
+	PartNumber  int       `json:"part_number"`
+	Bytes       int64     `json:"bytes"`
+	SHA256      string    `json:"sha256"`
+	StoragePath string    `json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// File is a completed file stored by the gateway
+type File struct {
+	ID          string    `json:"id"`
+	APIKeyID    string    `json:"api_key_id,omitempty"`
+	Filename    string    `json:"filename"`
+	Purpose     string    `json:"purpose"`
+	MimeType    string    `json:"mime_type,omitempty"`
+	Bytes       int64     `json:"bytes"`
+	SHA256      string    `json:"sha256"`
+	StoragePath string    `json:"-"`
+	Status      string    `json:"status"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	Storage        string     `json:"storage"`                    // FileStorageLocal, FileStorageObjectStore or FileStorageProvider
+	Provider       Provider   `json:"provider,omitempty"`         // Provider holding a passthrough file
+	ProviderFileID string     `json:"provider_file_id,omitempty"` // The provider's file ID or URI
+	Model          string     `json:"model,omitempty"`            // Model whose provider client uploaded a passthrough file
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`       // When retention deletes the file
+}
+
+// FileCapable is an optional interface for providers with a native files API,
+// used to pass uploaded files through to the provider
+type FileCapable interface {
+	// UploadFile stores the content with the provider and returns the
+	// reference that chat requests use for it
+	UploadFile(ctx context.Context, filename, mimeType string, size int64, content io.Reader) (string, error)
+	// DeleteFile deletes a file uploaded with UploadFile
+	DeleteFile(ctx context.Context, providerFileID string) error
+}
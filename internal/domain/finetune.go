@@ -0,0 +1,86 @@
+// Package domain defines fine-tuning job domain types.
+package domain
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// PermissionFineTuneWrite lets a role's API keys create and cancel
+// fine-tuning jobs
+const PermissionFineTuneWrite = "finetune:write"
+
+// FineTuneStatus is the lifecycle state of a fine-tuning job (OpenAI-compatible values)
+type FineTuneStatus string
+
+const (
+	FineTuneStatusValidatingFiles FineTuneStatus = "validating_files"
+	FineTuneStatusQueued          FineTuneStatus = "queued"
+	FineTuneStatusRunning         FineTuneStatus = "running"
+	FineTuneStatusSucceeded       FineTuneStatus = "succeeded"
+	FineTuneStatusFailed          FineTuneStatus = "failed"
+	FineTuneStatusCancelled       FineTuneStatus = "cancelled"
+)
+
+// IsTerminal reports whether the job will not change state again
+func (s FineTuneStatus) IsTerminal() bool {
+	switch s {
+	case FineTuneStatusSucceeded, FineTuneStatusFailed, FineTuneStatusCancelled:
+		return true
+	}
+	return false
+}
+
+// FineTuneJob is a fine-tuning job run by a provider on files uploaded to the
+// gateway. The gateway keeps its own record so that jobs can be listed and
+// audited per API key.
+type FineTuneJob struct {
+	ID               string         `json:"id"`
+	APIKeyID         string         `json:"api_key_id,omitempty"`
+	Provider         Provider       `json:"provider"`
+	Model            string         `json:"model"` // Base model, as requested
+	TrainingFileID   string         `json:"training_file"`
+	ValidationFileID string         `json:"validation_file,omitempty"`
+	Hyperparameters  map[string]any `json:"hyperparameters,omitempty"`
+	Suffix           string         `json:"suffix,omitempty"`
+	Status           FineTuneStatus `json:"status"`
+	ProviderJobID    string         `json:"provider_job_id,omitempty"`
+	FineTunedModel   string         `json:"fine_tuned_model,omitempty"` // The provider's name for the trained model
+	TrainedTokens    int64          `json:"trained_tokens,omitempty"`
+	Error            string         `json:"error,omitempty"`
+	Metadata         map[string]any `json:"metadata,omitempty"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	FinishedAt       *time.Time     `json:"finished_at,omitempty"`
+}
+
+// FineTuneRequest is a job as sent to the provider, with the provider's file IDs
+type FineTuneRequest struct {
+	Model           string
+	TrainingFile    string
+	ValidationFile  string
+	Hyperparameters map[string]any
+	Suffix          string
+}
+
+// ProviderFineTuneJob is the provider-side view of a fine-tuning job
+type ProviderFineTuneJob struct {
+	Status         FineTuneStatus
+	FineTunedModel string
+	TrainedTokens  int64
+	Error          string
+}
+
+// FineTuneCapable is an optional interface for providers with a fine-tuning API
+type FineTuneCapable interface {
+	// UploadTrainingFile uploads a JSONL training or validation file and
+	// returns the provider's file ID
+	UploadTrainingFile(ctx context.Context, filename string, content io.Reader) (string, error)
+	// CreateFineTuneJob starts a job and returns the provider's job ID
+	CreateFineTuneJob(ctx context.Context, req FineTuneRequest) (string, error)
+	// GetFineTuneJob polls the provider for the job state
+	GetFineTuneJob(ctx context.Context, providerJobID string) (*ProviderFineTuneJob, error)
+	// CancelFineTuneJob requests cancellation of a running job
+	CancelFineTuneJob(ctx context.Context, providerJobID string) error
+}
@@ -0,0 +1,45 @@
+package domain
+
+import "time"
+
+// Spend forecast scopes
+const (
+	ForecastScopeAPIKey = "api_key"
+	ForecastScopeRole   = "role"
+)
+
+// APIKeyDailyCost is one API key's cost on one UTC day
+type APIKeyDailyCost struct {
+	APIKeyID   string        `json:"api_key_id"`
+	APIKeyName string        `json:"api_key_name"`
+	RoleID     string        `json:"role_id,omitempty"`  // Empty for keys without a role
+	GroupID    string        `json:"group_id,omitempty"` // Empty for keys without a group
+	Budget     *APIKeyBudget `json:"budget,omitempty"`   // The key's own spending caps; nil = none
+	Day        time.Time     `json:"day"`
+	CostUSD    float64       `json:"cost_usd"`
+}
+
+// SpendForecast projects an API key's or a role's spend to the end of the
+// month from its recent burn rate
+type SpendForecast struct {
+	Scope        string     `json:"scope"` // ForecastScopeAPIKey or ForecastScopeRole
+	ID           string     `json:"id"`
+	Name         string     `json:"name"`
+	RoleID       string     `json:"role_id,omitempty"`      // The key's role, for API key forecasts
+	SpentUSD     float64    `json:"spent_usd"`              // Month to date
+	DailyBurnUSD float64    `json:"daily_burn_usd"`         // Average spend per day over the trailing window
+	ProjectedUSD float64    `json:"projected_usd"`          // Spend by month end at the burn rate
+	BudgetUSD    float64    `json:"budget_usd"`             // Monthly limit of the key's own budget, else of its role's budget policy; 0 when there is none
+	OverBudget   bool       `json:"over_budget"`            // Projected spend exceeds the budget
+	ExhaustedAt  *time.Time `json:"exhausted_at,omitempty"` // When spend reaches the budget at the burn rate, if before month end
+}
+
+// SpendForecastReport is the month-end forecast for every API key and role
+// with spend this month
+type SpendForecastReport struct {
+	PeriodStart time.Time        `json:"period_start"`
+	PeriodEnd   time.Time        `json:"period_end"`
+	GeneratedAt time.Time        `json:"generated_at"`
+	APIKeys     []*SpendForecast `json:"api_keys"` // Highest projection first
+	Roles       []*SpendForecast `json:"roles"`    // Highest projection first
+}
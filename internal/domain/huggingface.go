@@ -0,0 +1,42 @@
+package domain
+
+// HuggingFaceEndpoint is a dedicated Hugging Face Inference Endpoint. Clients
+// address it as "huggingface/<Name>"; other huggingface models go to the
+// serverless Inference API. Endpoints are billed by the hour while running,
+// so their requests are costed by the time they held the endpoint.
+type HuggingFaceEndpoint struct {
+	Name              string  `json:"name"`
+	URL               string  `json:"url"`             // e.g. https://xyz.us-east-1.aws.endpoints.huggingface.cloud
+	Model             string  `json:"model,omitempty"` // Model name sent to the server; defaults to "tgi"
+	HourlyCostUSD     float64 `json:"hourly_cost_usd"`
+	ContextWindow     int     `json:"context_window"`
+	MaxOutputTokens   int     `json:"max_output_tokens"`
+	SupportsTools     bool    `json:"supports_tools"`
+	SupportsVision    bool    `json:"supports_vision"`
+	SupportsStreaming bool    `json:"supports_streaming"`
+	Enabled           bool    `json:"enabled"`
+}
+
+// Upstream returns the model name the endpoint expects
+func (e *HuggingFaceEndpoint) Upstream() string {
+	if e.Model != "" {
+		return e.Model
+	}
+	return "tgi"
+}
+
+// ModelInfo describes the endpoint as it is listed in /v1/models. Endpoints
+// have no per-token price.
+func (e *HuggingFaceEndpoint) ModelInfo() ModelInfo {
+	return ModelInfo{
+		ID:             string(ProviderHuggingFace) + "/" + e.Name,
+		Name:           e.Name,
+		Provider:       ProviderHuggingFace,
+		SupportsTools:  e.SupportsTools,
+		SupportsVision: e.SupportsVision,
+		ContextLimit:   uint32(e.ContextWindow),
+		OutputLimit:    uint32(e.MaxOutputTokens),
+		Enabled:        e.Enabled,
+		NativeModelID:  e.Upstream(),
+	}
+}
@@ -0,0 +1,26 @@
+// Package domain defines idempotency key domain types.
+package domain
+
+import "time"
+
+// Idempotency key states
+const (
+	IdempotencyInProgress = "in_progress" // The first request with the key is still running
+	IdempotencyCompleted  = "completed"   // Its response is stored for replay
+)
+
+// IdempotencyRecord is the state of an Idempotency-Key sent by an API key.
+// A retried request with the same key gets the stored response instead of
+// being sent to the provider again.
+type IdempotencyRecord struct {
+	APIKeyID    string            `json:"api_key_id"`
+	Key         string            `json:"key"`
+	RequestHash string            `json:"request_hash"` // Fingerprint of the endpoint and body, to detect a key reused for another request
+	Status      string            `json:"status"`
+	StatusCode  int               `json:"status_code,omitempty"`
+	Header      map[string]string `json:"header,omitempty"` // Response headers replayed with the body
+	Body        []byte            `json:"-"`
+	LockedUntil time.Time         `json:"locked_until"` // An in-progress key whose request died is released after this
+	ExpiresAt   time.Time         `json:"expires_at"`
+	CreatedAt   time.Time         `json:"created_at"`
+}
@@ -0,0 +1,51 @@
+// Package domain defines prompt injection corpus domain types.
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// InjectionCategory groups the patterns of the injection corpus. Each has its
+// own similarity threshold in a role's ML detection settings.
+type InjectionCategory string
+
+const (
+	InjectionCategoryInjection InjectionCategory = "injection" // Overriding instructions, extracting the system prompt
+	InjectionCategoryJailbreak InjectionCategory = "jailbreak" // Role-play and persona attacks on the model's safeguards
+)
+
+// Valid reports whether c is a known category
+func (c InjectionCategory) Valid() bool {
+	return c == InjectionCategoryInjection || c == InjectionCategoryJailbreak
+}
+
+// InjectionPattern is a known jailbreak or prompt injection. Prompts are
+// compared with the patterns by embedding similarity, so rephrasings of a
+// pattern are caught too. Builtin patterns ship with the gateway; custom ones
+// are added by admins.
+type InjectionPattern struct {
+	ID        string            `json:"id"`
+	Text      string            `json:"text"`
+	Category  InjectionCategory `json:"category"`
+	Builtin   bool              `json:"builtin"`
+	Embedded  bool              `json:"embedded"` // False until embedded with the current embedder
+	CreatedBy string            `json:"created_by,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// InjectionMatch is the corpus pattern most similar to a prompt
+type InjectionMatch struct {
+	Pattern    InjectionPattern `json:"pattern"`
+	Similarity float64          `json:"similarity"` // Cosine similarity, 1 for the same meaning
+}
+
+// Outlier describes the match as an injection outlier
+func (m *InjectionMatch) Outlier() OutlierAnalysis {
+	return OutlierAnalysis{
+		IsOutlier:      true,
+		AnomalyScore:   m.Similarity,
+		OutlierReasons: []string{fmt.Sprintf("%.0f%% similar to known %s %q", m.Similarity*100, m.Pattern.Category, m.Pattern.Text)},
+		OutlierType:    OutlierTypeInjection,
+	}
+}
@@ -0,0 +1,37 @@
+// Package domain defines kill switch domain types.
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// KillSwitch stops traffic to a provider, or to one of its models, at runtime.
+// Routing avoids the target and direct requests to it fail with a 503.
+type KillSwitch struct {
+	Provider   Provider   `json:"provider"`
+	Model      string     `json:"model,omitempty"` // Empty for the whole provider
+	Reason     string     `json:"reason"`
+	DisabledBy string     `json:"disabled_by"` // Email of the admin who set it
+	DisabledAt time.Time  `json:"disabled_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"` // Traffic resumes at this time
+}
+
+// Target names what the switch stops: the provider, or provider/model
+func (k *KillSwitch) Target() string {
+	if k.Model == "" {
+		return string(k.Provider)
+	}
+	return string(k.Provider) + "/" + k.Model
+}
+
+// Active reports whether the switch still stops traffic at now
+func (k *KillSwitch) Active(now time.Time) bool {
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
+
+// KillSwitchModel returns a model ID without its provider prefix, the form
+// kill switches are keyed by
+func KillSwitchModel(provider Provider, model string) string {
+	return strings.TrimPrefix(model, string(provider)+"/")
+}
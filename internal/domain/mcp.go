@@ -221,12 +221,51 @@ type MCPToolPermission struct {
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+// MCP tool approval statuses
+const (
+	MCPApprovalPending  = "pending"
+	MCPApprovalApproved = "approved"
+	MCPApprovalDenied   = "denied"
+)
+
+// MCPToolApproval is a role's request to use an MCP tool it has no
+// permission for, queued for admins when the role first calls the tool.
+// Approving it grants the role the chosen visibility of the tool.
+type MCPToolApproval struct {
+	ID       string `json:"id"`
+	RoleID   string `json:"role_id"`
+	ServerID string `json:"server_id"`
+	ToolID   string `json:"tool_id"`
+
+	// Set when listing approvals
+	RoleName   string `json:"role_name,omitempty"`
+	ServerName string `json:"server_name,omitempty"`
+	ToolName   string `json:"tool_name,omitempty"`
+
+	Status           string    `json:"status"`                 // pending, approved, denied
+	RequestedBy      string    `json:"requested_by,omitempty"` // API key ID of the first call
+	RequestCount     int       `json:"request_count"`
+	FirstRequestedAt time.Time `json:"first_requested_at"`
+	LastRequestedAt  time.Time `json:"last_requested_at"`
+
+	// Decision
+	Visibility     MCPToolVisibility `json:"visibility,omitempty"` // Granted on approval
+	DecidedBy      string            `json:"decided_by,omitempty"`
+	DecidedByEmail string            `json:"decided_by_email,omitempty"`
+	DecidedAt      *time.Time        `json:"decided_at,omitempty"`
+	DecisionReason string            `json:"decision_reason,omitempty"`
+}
+
 // MCPToolExecution represents a tool execution log entry
 type MCPToolExecution struct {
 	ID       string `json:"id"`
 	ServerID string `json:"server_id"`
 	ToolID   string `json:"tool_id"`
 
+	// Set when listing executions
+	ServerName string `json:"server_name,omitempty"`
+	ToolName   string `json:"tool_name,omitempty"`
+
 	// Context
 	RoleID    string `json:"role_id,omitempty"`
 	APIKeyID  string `json:"api_key_id,omitempty"`
@@ -238,6 +277,9 @@ type MCPToolExecution struct {
 	Status       MCPExecutionStatus `json:"status"`
 	ErrorMessage string             `json:"error_message,omitempty"`
 
+	// Cost charged to the role's tool budget
+	CostUSD float64 `json:"cost_usd"`
+
 	// Timing
 	StartedAt   time.Time  `json:"started_at"`
 	CompletedAt *time.Time `json:"completed_at,omitempty"`
@@ -246,6 +288,67 @@ type MCPToolExecution struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// MCPToolExecutionFilter narrows tool execution logs and analytics
+type MCPToolExecutionFilter struct {
+	ServerID  string
+	ToolID    string
+	RoleID    string
+	APIKeyID  string
+	Status    MCPExecutionStatus
+	StartTime time.Time
+	EndTime   time.Time
+}
+
+// MCPToolExecutionStats aggregates the executions of one tool, server or
+// role. Durations cover calls that ran; blocked calls never did.
+type MCPToolExecutionStats struct {
+	ID            string // Tool, server or role ID; empty for totals
+	Name          string
+	Executions    int64
+	Errors        int64 // ERROR and TIMEOUT
+	Blocked       int64
+	AvgDurationMs float64
+	P95DurationMs float64
+	CostUSD       float64
+}
+
+// ErrorRate is the share of calls that ran and failed
+func (s *MCPToolExecutionStats) ErrorRate() float64 {
+	ran := s.Executions - s.Blocked
+	if ran <= 0 {
+		return 0
+	}
+	return float64(s.Errors) / float64(ran)
+}
+
+// MCPToolExecutionPoint is one time bucket of tool executions, for charts
+type MCPToolExecutionPoint struct {
+	Timestamp     time.Time
+	Executions    int64
+	Errors        int64
+	P95DurationMs float64
+}
+
+// MCPToolAnalytics summarizes the tool executions matching a filter
+type MCPToolAnalytics struct {
+	Totals          MCPToolExecutionStats
+	ByTool          []*MCPToolExecutionStats
+	ByServer        []*MCPToolExecutionStats
+	ByRole          []*MCPToolExecutionStats
+	TimeSeries      []*MCPToolExecutionPoint
+	TopFailingTools []*MCPToolExecutionStats // Most errors first
+}
+
+// MCPToolUsage is a role's recent tool usage, checked against its MCP limits.
+// Blocked calls are not counted.
+type MCPToolUsage struct {
+	LastMinute   int        // Calls in the last minute
+	Today        int        // Calls since the start of the UTC day
+	LastToolCall *time.Time // Last call of the tool being invoked
+	CostToday    float64
+	CostMonth    float64
+}
+
 // MCPExecutionStatus represents the status of a tool execution
 type MCPExecutionStatus string
 
@@ -411,5 +514,6 @@ type MCPRepository interface {
 
 	// Executions
 	LogMCPToolExecution(ctx any, exec *MCPToolExecution) error
-	ListMCPToolExecutions(ctx any, tenantID string, limit, offset int) ([]*MCPToolExecution, int, error)
+	ListMCPToolExecutions(ctx any, tenantID string, filter MCPToolExecutionFilter, limit, offset int) ([]*MCPToolExecution, int, error)
+	GetMCPToolAnalytics(ctx any, tenantID string, filter MCPToolExecutionFilter, interval string) (*MCPToolAnalytics, error)
 }
@@ -0,0 +1,20 @@
+// Package domain defines model deprecation domain types.
+package domain
+
+import "time"
+
+// ModelDeprecation is a provider's announced retirement of a model in the
+// catalog (available_models)
+type ModelDeprecation struct {
+	Provider         Provider   `json:"provider"`
+	ModelID          string     `json:"model_id"`
+	SunsetAt         time.Time  `json:"sunset_at"`                   // When the provider stops serving the model
+	ReplacementModel string     `json:"replacement_model,omitempty"` // Model to move to, as clients request it
+	Notice           string     `json:"notice,omitempty"`            // Shown to admins, e.g. a link to the provider's announcement
+	AlertedAt        *time.Time `json:"alerted_at,omitempty"`        // When admins were alerted about the sunset
+}
+
+// Sunset reports whether the model has been retired at now
+func (d *ModelDeprecation) Sunset(now time.Time) bool {
+	return !now.Before(d.SunsetAt)
+}
@@ -0,0 +1,30 @@
+// Package domain defines model discovery domain types.
+package domain
+
+import "time"
+
+// CatalogModel is a provider model's entry in available_models, as model
+// discovery compares it with the provider's listing
+type CatalogModel struct {
+	ID         string    `json:"id"`
+	Available  bool      `json:"available"`
+	Deprecated bool      `json:"deprecated"` // The provider stopped listing it
+	FetchedAt  time.Time `json:"fetched_at"` // Last time the provider listed it
+}
+
+// ModelDiscoveryReport is what one discovery run found for a provider: models
+// it lists for the first time, models it stopped listing (deprecated), and
+// deprecated models dropped from the catalog (removed)
+type ModelDiscoveryReport struct {
+	Provider   Provider `json:"provider"`
+	Listed     int      `json:"listed"`
+	Added      []string `json:"added,omitempty"`
+	Deprecated []string `json:"deprecated,omitempty"`
+	Removed    []string `json:"removed,omitempty"`
+	Error      string   `json:"error,omitempty"` // Set when the provider couldn't be listed; its catalog is left as it was
+}
+
+// Changed reports whether the run changed the provider's catalog
+func (r *ModelDiscoveryReport) Changed() bool {
+	return len(r.Added) > 0 || len(r.Deprecated) > 0 || len(r.Removed) > 0
+}
@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// ParseCIDR parses an allowlist entry. A bare address is treated as a
+// single-host prefix (/32 or /128).
+func ParseCIDR(s string) (netip.Prefix, error) {
+	s = strings.TrimSpace(s)
+	if !strings.Contains(s, "/") {
+		addr, err := netip.ParseAddr(s)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("invalid IP or CIDR %q", s)
+		}
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+	prefix, err := netip.ParsePrefix(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid IP or CIDR %q", s)
+	}
+	return prefix.Masked(), nil
+}
+
+// NormalizeCIDRs validates allowlist entries and returns them in canonical form
+func NormalizeCIDRs(entries []string) ([]string, error) {
+	out := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if strings.TrimSpace(e) == "" {
+			continue
+		}
+		prefix, err := ParseCIDR(e)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, prefix.String())
+	}
+	return out, nil
+}
+
+// CIDRsContain reports whether ip falls within any of the allowlist entries.
+// Invalid entries never match.
+func CIDRsContain(entries []string, ip netip.Addr) bool {
+	ip = ip.Unmap()
+	for _, e := range entries {
+		if prefix, err := ParseCIDR(e); err == nil && prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+// Package domain defines model pricing domain types.
+package domain
+
+import "time"
+
+// PriceSource identifies where a model price came from
+type PriceSource string
+
+const (
+	PriceSourceCatalog  PriceSource = "catalog"  // Bundled pricing catalog
+	PriceSourceProvider PriceSource = "provider" // Reported by the provider's models endpoint
+	PriceSourceConfig   PriceSource = "config"   // [models] pricing in config.toml
+	PriceSourceOverride PriceSource = "override" // Set by an admin
+)
+
+// ModelPrice is one entry in a model's pricing history. A price applies from
+// EffectiveFrom until a newer entry from the same source takes over. An
+// override entry with Reset set ends the previous override.
+type ModelPrice struct {
+	ID              string   `json:"id"`
+	Provider        Provider `json:"provider"`
+	ModelID         string   `json:"model_id"`
+	InputCostPer1M  float64  `json:"input_cost_per_1m"`
+	OutputCostPer1M float64  `json:"output_cost_per_1m"`
+	// CachedInputCostPer1M prices prompt tokens served from the provider's
+	// context cache; 0 bills them at the full input price
+	CachedInputCostPer1M float64     `json:"cached_input_cost_per_1m,omitempty"`
+	Source               PriceSource `json:"source"`
+	Reset                bool        `json:"reset,omitempty"`
+	EffectiveFrom        time.Time   `json:"effective_from"`
+	Note                 string      `json:"note,omitempty"`
+	CreatedBy            string      `json:"created_by,omitempty"`
+	CreatedAt            time.Time   `json:"created_at"`
+}
+
+// Cost prices token usage in USD
+func (p *ModelPrice) Cost(inputTokens, outputTokens int64) float64 {
+	return float64(inputTokens)/1_000_000.0*p.InputCostPer1M + float64(outputTokens)/1_000_000.0*p.OutputCostPer1M
+}
+
+// CostWithCache prices token usage in USD where cachedTokens of the input
+// tokens were served from the provider's context cache
+func (p *ModelPrice) CostWithCache(inputTokens, cachedTokens, outputTokens int64) float64 {
+	if cachedTokens <= 0 || p.CachedInputCostPer1M <= 0 {
+		return p.Cost(inputTokens, outputTokens)
+	}
+	cachedTokens = min(cachedTokens, inputTokens)
+	return p.Cost(inputTokens-cachedTokens, outputTokens) + float64(cachedTokens)/1_000_000.0*p.CachedInputCostPer1M
+}
+
+// CacheSavings is what the context cache saved over billing every input token at the full price
+func (p *ModelPrice) CacheSavings(inputTokens, cachedTokens, outputTokens int64) float64 {
+	return p.Cost(inputTokens, outputTokens) - p.CostWithCache(inputTokens, cachedTokens, outputTokens)
+}
+
+// ModelPriceFilter filters pricing history
+type ModelPriceFilter struct {
+	Provider Provider
+	ModelID  string
+	Source   PriceSource
+}
@@ -0,0 +1,34 @@
+// Package domain defines project domain types.
+package domain
+
+import "time"
+
+// Project groups API keys under the tenant, typically one per team. Each
+// project has its own budget, and usage is rolled up per project.
+type Project struct {
+	ID             string       `json:"id"`
+	Name           string       `json:"name"`
+	Description    string       `json:"description,omitempty"`
+	BudgetPolicy   BudgetPolicy `json:"budget_policy"`
+	APIKeyCount    int          `json:"api_key_count"`
+	CreatedBy      string       `json:"created_by,omitempty"`
+	CreatedByEmail string       `json:"created_by_email,omitempty"`
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+}
+
+// ProjectSpend is a project's cost so far in each budget window
+type ProjectSpend struct {
+	DailyUSD   float64 `json:"daily_usd"`
+	WeeklyUSD  float64 `json:"weekly_usd"`
+	MonthlyUSD float64 `json:"monthly_usd"`
+}
+
+// ProjectUsageStats contains per-project usage statistics
+type ProjectUsageStats struct {
+	ProjectID   string  `json:"project_id"`
+	ProjectName string  `json:"project_name"`
+	Requests    int64   `json:"requests"`
+	TotalTokens int64   `json:"total_tokens"`
+	CostUSD     float64 `json:"cost_usd"`
+}
@@ -0,0 +1,131 @@
+package domain
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Normalized provider error codes. They follow OpenAI's error codes so that
+// OpenAI-compatible clients can handle any provider's failures the same way,
+// and are what usage records store as ErrorCode.
+const (
+	ErrorCodeRateLimit         = "rate_limit_exceeded"
+	ErrorCodeInsufficientQuota = "insufficient_quota"
+	ErrorCodeContextLength     = "context_length_exceeded"
+	ErrorCodeContentFilter     = "content_filter"
+	ErrorCodeInvalidAPIKey     = "invalid_api_key"
+	ErrorCodeModelNotFound     = "model_not_found"
+	ErrorCodeInvalidRequest    = "invalid_request"
+	ErrorCodeTimeout           = "timeout"
+	ErrorCodeOverloaded        = "provider_overloaded"
+	ErrorCodeCancelled         = "request_cancelled"
+	ErrorCodeProviderError     = "provider_error"
+
+	// Pre-flight capability checks, returned before the request reaches a
+	// model that is known not to accept it
+	ErrorCodeToolsNotSupported     = "tools_not_supported"
+	ErrorCodeVisionNotSupported    = "vision_not_supported"
+	ErrorCodeReasoningNotSupported = "reasoning_not_supported"
+	ErrorCodeGrammarNotSupported   = "grammar_not_supported"
+
+	// The request's projected cost is over its X-ModelGate-Max-Cost cap or
+	// the role's per-request maximum
+	ErrorCodeCostLimitExceeded = "cost_limit_exceeded"
+
+	// The conversation reached a per-conversation ceiling of the role's budget
+	ErrorCodeConversationBudgetExceeded = "conversation_budget_exceeded"
+
+	// An admin switched off traffic to the provider or model
+	ErrorCodeProviderDisabled = "provider_disabled"
+	ErrorCodeModelDisabled    = "model_disabled"
+)
+
+// OpenAI error types returned with the codes above
+const (
+	ErrorTypeInvalidRequest = "invalid_request_error"
+	ErrorTypeRateLimit      = "rate_limit_error"
+	ErrorTypeAPI            = "api_error"
+)
+
+// StatusClientClosedRequest is returned when the client went away before the
+// provider answered (nginx's non-standard 499)
+const StatusClientClosedRequest = 499
+
+// errorCodeStatus maps each code to the gateway's HTTP status and error type.
+// An upstream key the provider rejects is the gateway's problem, not the
+// caller's, so it is a 502 rather than a 401.
+var errorCodeStatus = map[string]struct {
+	status  int
+	errType string
+}{
+	ErrorCodeRateLimit:         {http.StatusTooManyRequests, ErrorTypeRateLimit},
+	ErrorCodeInsufficientQuota: {http.StatusTooManyRequests, ErrorTypeRateLimit},
+	ErrorCodeContextLength:     {http.StatusBadRequest, ErrorTypeInvalidRequest},
+	ErrorCodeContentFilter:     {http.StatusBadRequest, ErrorTypeInvalidRequest},
+	ErrorCodeInvalidAPIKey:     {http.StatusBadGateway, ErrorTypeAPI},
+	ErrorCodeModelNotFound:     {http.StatusNotFound, ErrorTypeInvalidRequest},
+	ErrorCodeInvalidRequest:    {http.StatusBadRequest, ErrorTypeInvalidRequest},
+	ErrorCodeTimeout:           {http.StatusGatewayTimeout, ErrorTypeAPI},
+	ErrorCodeOverloaded:        {http.StatusServiceUnavailable, ErrorTypeAPI},
+	ErrorCodeCancelled:         {StatusClientClosedRequest, ErrorTypeAPI},
+	ErrorCodeProviderError:     {http.StatusBadGateway, ErrorTypeAPI},
+
+	ErrorCodeToolsNotSupported:     {http.StatusBadRequest, ErrorTypeInvalidRequest},
+	ErrorCodeVisionNotSupported:    {http.StatusBadRequest, ErrorTypeInvalidRequest},
+	ErrorCodeReasoningNotSupported: {http.StatusBadRequest, ErrorTypeInvalidRequest},
+	ErrorCodeGrammarNotSupported:   {http.StatusBadRequest, ErrorTypeInvalidRequest},
+
+	ErrorCodeCostLimitExceeded:          {http.StatusPaymentRequired, ErrorTypeInvalidRequest},
+	ErrorCodeConversationBudgetExceeded: {http.StatusPaymentRequired, ErrorTypeInvalidRequest},
+	ErrorCodeProviderDisabled:           {http.StatusServiceUnavailable, ErrorTypeAPI},
+	ErrorCodeModelDisabled:              {http.StatusServiceUnavailable, ErrorTypeAPI},
+}
+
+// ProviderError is an upstream provider failure normalized to an
+// OpenAI-style error
+type ProviderError struct {
+	Provider       Provider `json:"provider,omitempty"`
+	Code           string   `json:"code"`
+	Type           string   `json:"type"`
+	Status         int      `json:"-"` // HTTP status the gateway responds with
+	UpstreamStatus int      `json:"upstream_status,omitempty"`
+	Message        string   `json:"message"`
+	Err            error    `json:"-"` // Underlying error, if it wasn't an HTTP response
+
+	// RetryAfter is how long the caller should wait before retrying, when known.
+	// It is sent as the Retry-After header.
+	RetryAfter time.Duration `json:"-"`
+}
+
+// NewProviderError builds a ProviderError for code, filling in its status and type
+func NewProviderError(provider Provider, code, message string) *ProviderError {
+	m, ok := errorCodeStatus[code]
+	if !ok {
+		code, m = ErrorCodeProviderError, errorCodeStatus[ErrorCodeProviderError]
+	}
+	return &ProviderError{Provider: provider, Code: code, Type: m.errType, Status: m.status, Message: message}
+}
+
+func (e *ProviderError) Error() string {
+	prefix := e.Code
+	if e.Provider != "" {
+		prefix = string(e.Provider) + ": " + e.Code
+	}
+	if e.UpstreamStatus != 0 {
+		return fmt.Sprintf("%s (HTTP %d): %s", prefix, e.UpstreamStatus, e.Message)
+	}
+	return fmt.Sprintf("%s: %s", prefix, e.Message)
+}
+
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorEvent reports a provider failure on a stream. The stream then ends
+// with a FinishEvent whose reason is FinishReasonError.
+type ErrorEvent struct {
+	Err *ProviderError `json:"error"`
+}
+
+func (ErrorEvent) eventType() string { return "error" }
@@ -0,0 +1,49 @@
+// Package domain defines provider health history domain types.
+package domain
+
+import "time"
+
+// ProviderHealthSample is a provider/model's requests over one time bucket.
+// Model is empty on the provider's own sample, which covers all its models.
+type ProviderHealthSample struct {
+	BucketStart        time.Time `json:"bucket_start"`
+	BucketSeconds      int       `json:"bucket_seconds"`
+	Provider           string    `json:"provider"`
+	Model              string    `json:"model,omitempty"`
+	Requests           int64     `json:"requests"`
+	Failures           int64     `json:"failures"`      // Failures the provider is answerable for: timeouts, overload, rate limits and server errors
+	ClientErrors       int64     `json:"client_errors"` // Requests refused for the caller's fault, e.g. invalid or too long; not counted as requests
+	P50LatencyMs       float64   `json:"p50_latency_ms"`
+	P95LatencyMs       float64   `json:"p95_latency_ms"`
+	P99LatencyMs       float64   `json:"p99_latency_ms"`
+	BreakerState       string    `json:"breaker_state"`        // Worst circuit breaker state in the bucket: closed, half_open or open
+	BreakerOpenSeconds float64   `json:"breaker_open_seconds"` // Time the provider's circuit breaker was open in the bucket
+}
+
+// SuccessRate returns the fraction of the sample's requests that succeeded,
+// 1 without requests
+func (s *ProviderHealthSample) SuccessRate() float64 {
+	if s.Requests == 0 {
+		return 1
+	}
+	return float64(s.Requests-s.Failures) / float64(s.Requests)
+}
+
+// ProviderSLAReport is a provider/model's service level over a calendar month
+// (UTC). Model is empty on the provider-wide report.
+type ProviderSLAReport struct {
+	Month               time.Time `json:"month"` // First day of the month
+	Provider            string    `json:"provider"`
+	Model               string    `json:"model,omitempty"`
+	Requests            int64     `json:"requests"`
+	Failures            int64     `json:"failures"`
+	AvailabilityPercent float64   `json:"availability_percent"` // Successful requests out of all requests
+	UptimePercent       float64   `json:"uptime_percent"`       // Time of the month the provider was neither degraded nor behind an open breaker
+	DowntimeMinutes     float64   `json:"downtime_minutes"`
+	BreakerOpenMinutes  float64   `json:"breaker_open_minutes"`
+	P50LatencyMs        float64   `json:"p50_latency_ms"` // Request-weighted averages of the buckets' percentiles
+	P95LatencyMs        float64   `json:"p95_latency_ms"`
+	P99LatencyMs        float64   `json:"p99_latency_ms"`
+	TargetPercent       float64   `json:"target_percent"`
+	TargetMet           bool      `json:"target_met"` // Availability and uptime both reached the target
+}
@@ -0,0 +1,76 @@
+package domain
+
+import "time"
+
+// providerQuotaTTL is how long an observation without reset information is trusted
+const providerQuotaTTL = time.Minute
+
+// ProviderQuotaWindow is one upstream rate limit window (requests or tokens)
+// as reported by a provider's response headers
+type ProviderQuotaWindow struct {
+	Limit     int       `json:"limit"` // 0 when the provider didn't report it
+	Remaining int       `json:"remaining"`
+	ResetAt   time.Time `json:"reset_at,omitzero"` // Zero when the provider didn't report it
+}
+
+// low reports whether the window has less than threshold of its limit left
+// and hasn't reset yet
+func (w *ProviderQuotaWindow) low(threshold float64, now time.Time) bool {
+	if w == nil || (!w.ResetAt.IsZero() && !now.Before(w.ResetAt)) {
+		return false
+	}
+	if w.Remaining <= 0 {
+		return true
+	}
+	return w.Limit > 0 && float64(w.Remaining) < threshold*float64(w.Limit)
+}
+
+// ProviderQuota is the rate limit state a provider reported for an API key
+type ProviderQuota struct {
+	Requests   *ProviderQuotaWindow `json:"requests,omitempty"`
+	Tokens     *ProviderQuotaWindow `json:"tokens,omitempty"`
+	RetryAt    time.Time            `json:"retry_at,omitzero"` // From a Retry-After header
+	ObservedAt time.Time            `json:"observed_at"`
+}
+
+// ExpiresAt returns when the observation stops describing the key: the latest
+// window reset, or a short TTL when the provider sent no reset times
+func (q ProviderQuota) ExpiresAt() time.Time {
+	expires := q.RetryAt
+	for _, w := range []*ProviderQuotaWindow{q.Requests, q.Tokens} {
+		if w != nil && w.ResetAt.After(expires) {
+			expires = w.ResetAt
+		}
+	}
+	if !expires.After(q.ObservedAt) {
+		expires = q.ObservedAt.Add(providerQuotaTTL)
+	}
+	return expires
+}
+
+// Low reports whether the key is about to run out of quota: a Retry-After is
+// still in effect, or a window has less than threshold (0.0-1.0) of its limit left
+func (q ProviderQuota) Low(threshold float64, now time.Time) bool {
+	if !now.Before(q.ExpiresAt()) {
+		return false
+	}
+	if now.Before(q.RetryAt) {
+		return true
+	}
+	return q.Requests.low(threshold, now) || q.Tokens.low(threshold, now)
+}
+
+// Merge folds a newer observation into q. Windows and a Retry-After the newer
+// response didn't report keep their previous values.
+func (q ProviderQuota) Merge(next ProviderQuota) ProviderQuota {
+	if next.Requests == nil {
+		next.Requests = q.Requests
+	}
+	if next.Tokens == nil {
+		next.Tokens = q.Tokens
+	}
+	if next.RetryAt.IsZero() && q.RetryAt.After(next.ObservedAt) {
+		next.RetryAt = q.RetryAt
+	}
+	return next
+}
@@ -0,0 +1,37 @@
+// Package domain defines durable queue domain types.
+package domain
+
+import "time"
+
+// QueuedRequestStatus is the state of a request in the durable dispatcher queue
+type QueuedRequestStatus string
+
+const (
+	QueuedRequestQueued     QueuedRequestStatus = "queued"
+	QueuedRequestProcessing QueuedRequestStatus = "processing"
+	QueuedRequestCompleted  QueuedRequestStatus = "completed"
+	QueuedRequestFailed     QueuedRequestStatus = "failed"
+)
+
+// IsTerminal reports whether the request will not change state again
+func (s QueuedRequestStatus) IsTerminal() bool {
+	return s == QueuedRequestCompleted || s == QueuedRequestFailed
+}
+
+// QueuedRequest is a non-streaming request persisted by the dispatcher so it
+// survives a restart. IdempotencyKey is unique per API key.
+type QueuedRequest struct {
+	ID             string              `json:"id"`
+	IdempotencyKey string              `json:"idempotency_key"`
+	APIKeyID       string              `json:"api_key_id,omitempty"`
+	RoleID         string              `json:"role_id,omitempty"`
+	GroupID        string              `json:"group_id,omitempty"`
+	Priority       int                 `json:"priority"`
+	Request        *ChatRequest        `json:"request"`
+	Status         QueuedRequestStatus `json:"status"`
+	Response       *ChatResponse       `json:"response,omitempty"`
+	Error          string              `json:"error,omitempty"`
+	EnqueuedAt     time.Time           `json:"enqueued_at"`
+	StartedAt      *time.Time          `json:"started_at,omitempty"`
+	CompletedAt    *time.Time          `json:"completed_at,omitempty"`
+}
@@ -0,0 +1,22 @@
+// Package domain defines billing period quota domain types.
+package domain
+
+import "time"
+
+// QuotaLimits are the configured per-period limits (0 = unlimited). New
+// periods start with these; a change mid-period is prorated into the open one.
+type QuotaLimits struct {
+	RequestsLimit int64     `json:"requests_limit"`
+	TokensLimit   int64     `json:"tokens_limit"`
+	CostLimitUSD  float64   `json:"cost_limit_usd"`
+	UpdatedBy     string    `json:"updated_by,omitempty"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// QuotaPeriod is one billing period's effective limits and usage. The open
+// period's usage is computed on demand; a closed period keeps a snapshot.
+type QuotaPeriod struct {
+	ID string `json:"id"`
+	TenantQuotas
+	ClosedAt *time.Time `json:"closed_at,omitempty"`
+}
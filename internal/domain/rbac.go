@@ -2,6 +2,9 @@
 package domain
 
 import (
+	"fmt"
+	"regexp"
+	"strings"
 	"time"
 )
 
@@ -24,11 +27,57 @@ type Role struct {
 	UpdatedAt      time.Time   `json:"updated_at"`
 }
 
+// HasPermission reports whether the role grants perm
+func (r *Role) HasPermission(perm string) bool {
+	return GrantsPermission(r.Permissions, perm)
+}
+
+// GrantsPermission reports whether a list of permissions grants perm. A
+// permission of "*" grants everything and "keys:*" grants every "keys:"
+// permission.
+func GrantsPermission(perms []string, perm string) bool {
+	for _, p := range perms {
+		if p == "*" || p == perm {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(p, "*"); ok && strings.HasPrefix(perm, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
 // MCPPolicies defines policies for MCP Gateway functionality
 type MCPPolicies struct {
 	Enabled            bool `json:"enabled"`
 	AllowToolSearch    bool `json:"allow_tool_search"`
 	AuditToolExecution bool `json:"audit_tool_execution"`
+	ListDeniedTools    bool `json:"list_denied_tools"` // tools/list shows denied tools as disabled, with the reason, instead of hiding them
+
+	// Invocation limits across all tools; 0 = unlimited
+	MaxInvocationsPerMinute int     `json:"max_invocations_per_minute"`
+	MaxInvocationsPerDay    int     `json:"max_invocations_per_day"`
+	DailyBudgetUSD          float64 `json:"daily_budget_usd"`   // Spend on paid tools per UTC day
+	MonthlyBudgetUSD        float64 `json:"monthly_budget_usd"` // Spend on paid tools per UTC month
+
+	ToolLimits []MCPToolLimit `json:"tool_limits,omitempty"`
+}
+
+// MCPToolLimit sets a cooldown and per-call cost for one MCP tool
+type MCPToolLimit struct {
+	Tool            string  `json:"tool"`             // Qualified name: server_slug__tool_name
+	CooldownSeconds int     `json:"cooldown_seconds"` // Minimum time between calls by the role; 0 = none
+	CostPerCallUSD  float64 `json:"cost_per_call_usd"`
+}
+
+// ToolLimit returns the limit configured for a qualified tool name, or nil
+func (p *MCPPolicies) ToolLimit(tool string) *MCPToolLimit {
+	for i := range p.ToolLimits {
+		if p.ToolLimits[i].Tool == tool {
+			return &p.ToolLimits[i]
+		}
+	}
+	return nil
 }
 
 // RolePolicy associates a role with policy configurations
@@ -44,11 +93,15 @@ type RolePolicy struct {
 	ModelRestriction ModelRestrictions `json:"model_restrictions"`
 
 	// Extended Policies (5) - Policy-Driven Features
-	CachingPolicy     CachingPolicy     `json:"caching_policy"`
-	RoutingPolicy     RoutingPolicy     `json:"routing_policy"`
-	ResiliencePolicy  ResiliencePolicy  `json:"resilience_policy"`
-	BudgetPolicy      BudgetPolicy      `json:"budget_policy"`
-	ConcurrencyPolicy ConcurrencyPolicy `json:"concurrency_policy"`
+	CachingPolicy     CachingPolicy         `json:"caching_policy"`
+	RoutingPolicy     RoutingPolicy         `json:"routing_policy"`
+	ResiliencePolicy  ResiliencePolicy      `json:"resilience_policy"`
+	BudgetPolicy      BudgetPolicy          `json:"budget_policy"`
+	ConcurrencyPolicy ConcurrencyPolicy     `json:"concurrency_policy"`
+	NetworkPolicy     NetworkPolicy         `json:"network_policy"`
+	FilePolicy        FilePolicy            `json:"file_policy"`
+	GenerationPolicy  GenerationPolicy      `json:"generation_policy"`
+	OutputTransform   OutputTransformPolicy `json:"output_transform_policy"`
 
 	// MCP Gateway Policy
 	MCPPolicies MCPPolicies `json:"mcp_policies"`
@@ -61,6 +114,133 @@ type RolePolicy struct {
 type ConcurrencyPolicy struct {
 	Enabled  bool `json:"enabled"`
 	Priority int  `json:"priority"` // 0-10, higher = processed first
+	Weight   int  `json:"weight"`   // Share of its priority band under load relative to other roles; 0 = 1
+
+	// In-flight limits; 0 falls back to the [server] defaults
+	MaxConcurrentPerKey  int  `json:"max_concurrent_per_key"`  // Per API key holding this role
+	MaxConcurrentPerRole int  `json:"max_concurrent_per_role"` // Across all API keys holding this role
+	QueueWhenLimited     bool `json:"queue_when_limited"`      // Wait for a free slot instead of rejecting
+}
+
+// NetworkPolicy restricts where API keys holding a role may connect from
+type NetworkPolicy struct {
+	Enabled           bool     `json:"enabled"`
+	AllowedCIDRs      []string `json:"allowed_cidrs"`       // Client IP must fall in one of these; empty = any
+	RequireClientCert bool     `json:"require_client_cert"` // Require a verified mTLS client certificate
+}
+
+// FilePolicy restricts the files API keys holding a role may upload
+type FilePolicy struct {
+	Enabled          bool     `json:"enabled"`
+	MaxFileSize      int64    `json:"max_file_size"`      // Bytes; 0 = the [files] max_file_size
+	AllowedMimeTypes []string `json:"allowed_mime_types"` // Exact types or wildcards such as "image/*"; empty = any
+	RetentionDays    int      `json:"retention_days"`     // Files are deleted this many days after upload; 0 = the [files] retention
+}
+
+// AllowsMimeType reports whether the policy lets files of mimeType be uploaded
+func (p *FilePolicy) AllowsMimeType(mimeType string) bool {
+	if !p.Enabled || len(p.AllowedMimeTypes) == 0 {
+		return true
+	}
+	mimeType = strings.ToLower(strings.TrimSpace(strings.SplitN(mimeType, ";", 2)[0]))
+	for _, allowed := range p.AllowedMimeTypes {
+		allowed = strings.ToLower(allowed)
+		if allowed == mimeType || allowed == "*/*" {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(allowed, "/*"); ok && strings.HasPrefix(mimeType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// GenerationPolicy pins the sampling parameters of requests from API keys
+// holding a role. Defaults fill in parameters a request leaves out; values
+// outside the bounds are clamped to them. Unset fields leave the parameter
+// to the client.
+type GenerationPolicy struct {
+	Enabled            bool     `json:"enabled"`
+	DefaultTemperature *float32 `json:"default_temperature,omitempty"`
+	MinTemperature     *float32 `json:"min_temperature,omitempty"`
+	MaxTemperature     *float32 `json:"max_temperature,omitempty"`
+	DefaultTopP        *float32 `json:"default_top_p,omitempty"`
+	MaxTopP            *float32 `json:"max_top_p,omitempty"`
+	DefaultMaxTokens   int32    `json:"default_max_tokens,omitempty"` // 0 = the provider's default
+	MaxTokensLimit     int32    `json:"max_tokens_limit,omitempty"`   // 0 = no cap
+}
+
+// Validate checks the policy's bounds before it's saved
+func (p *GenerationPolicy) Validate() error {
+	for _, b := range []struct {
+		name     string
+		value    *float32
+		min, max float32
+	}{
+		{"default_temperature", p.DefaultTemperature, 0, 2},
+		{"min_temperature", p.MinTemperature, 0, 2},
+		{"max_temperature", p.MaxTemperature, 0, 2},
+		{"default_top_p", p.DefaultTopP, 0, 1},
+		{"max_top_p", p.MaxTopP, 0, 1},
+	} {
+		if b.value != nil && (*b.value < b.min || *b.value > b.max) {
+			return fmt.Errorf("%s must be between %g and %g", b.name, b.min, b.max)
+		}
+	}
+	if p.MinTemperature != nil && p.MaxTemperature != nil && *p.MinTemperature > *p.MaxTemperature {
+		return fmt.Errorf("min_temperature is above max_temperature")
+	}
+	if p.DefaultMaxTokens < 0 || p.MaxTokensLimit < 0 {
+		return fmt.Errorf("max tokens can't be negative")
+	}
+	return nil
+}
+
+// OutputTransformPolicy rewrites the text of responses to API keys holding a
+// role before it reaches the client, in this order: reasoning blocks are
+// dropped, citation markers stripped, markdown converted to plain text, the
+// rewrite rules applied in order, and the result truncated to MaxLength.
+// Streams are transformed a line at a time, so rewrite patterns don't match
+// across line breaks.
+type OutputTransformPolicy struct {
+	Enabled          bool                `json:"enabled"`
+	StripReasoning   bool                `json:"strip_reasoning"` // Drop <think> blocks and reasoning output
+	StripCitations   bool                `json:"strip_citations"` // Drop markers such as [1], [^2] and 【3†source】
+	StripMarkdown    bool                `json:"strip_markdown"`  // Convert markdown to plain text
+	Rewrites         []OutputRewriteRule `json:"rewrites,omitempty"`
+	MaxLength        int                 `json:"max_length"`        // Characters; 0 = no limit
+	TruncationSuffix string              `json:"truncation_suffix"` // Appended when MaxLength cut the text
+}
+
+// OutputRewriteRule replaces matches of a regular expression (RE2 syntax).
+// The replacement may refer to groups as $1 or ${name}.
+type OutputRewriteRule struct {
+	Name        string `json:"name,omitempty"`
+	Pattern     string `json:"pattern"`
+	Replacement string `json:"replacement"`
+}
+
+// Validate checks the policy's patterns compile before it's saved
+func (p *OutputTransformPolicy) Validate() error {
+	for i, r := range p.Rewrites {
+		if r.Pattern == "" {
+			return fmt.Errorf("rewrite %d has no pattern", i+1)
+		}
+		if _, err := regexp.Compile(r.Pattern); err != nil {
+			return fmt.Errorf("rewrite %d: %w", i+1, err)
+		}
+	}
+	if p.MaxLength < 0 {
+		return fmt.Errorf("max_length can't be negative")
+	}
+	return nil
+}
+
+// OutputTransform records how an output transform policy changed a response
+type OutputTransform struct {
+	Applied       []string `json:"applied"` // reasoning, citations, markdown, rewrite:<name> or truncate
+	OriginalChars int      `json:"original_chars"`
+	Chars         int      `json:"chars"` // As sent to the client
 }
 
 // =============================================================================
@@ -357,6 +537,9 @@ type OutputValidationConfig struct {
 	// Content policy
 	ApplyContentFiltering bool `json:"apply_content_filtering"`
 
+	// Streaming: characters held back and rescanned across chunk boundaries (0 = default)
+	StreamWindowChars int `json:"stream_window_chars"`
+
 	// Action
 	OnViolation OutputViolationAction `json:"on_violation"`
 }
@@ -372,6 +555,14 @@ const (
 	OutputActionRegenerate OutputViolationAction = "regenerate"
 )
 
+// OutputViolation describes generated content caught by output moderation
+type OutputViolation struct {
+	Scanner   string                `json:"scanner"`          // secret, pii, code_execution, sql, shell, html_script, system_prompt
+	Detail    string                `json:"detail,omitempty"` // Pattern or PII category that matched
+	Action    OutputViolationAction `json:"action"`
+	Truncated bool                  `json:"truncated"` // The stream was cut off at the violation
+}
+
 // =============================================================================
 // Tool Policy Types
 // =============================================================================
@@ -418,14 +609,35 @@ type RateLimitPolicy struct {
 	CostPerDayUSD    float64 `json:"cost_per_day_usd"`
 	CostPerMonthUSD  float64 `json:"cost_per_month_usd"`
 
-	// Burst settings
-	BurstLimit  int   `json:"burst_limit"`
-	BurstTokens int64 `json:"burst_tokens"`
+	// Burst settings: with a multiplier > 1 a key may exceed RequestsPerMinute/TokensPerMinute by
+	// borrowing against the next window, which then starts with the borrowed amount already spent.
+	// BurstLimit and BurstTokens cap the credits borrowable per window (0 = no cap).
+	BurstMultiplier float64 `json:"burst_multiplier"` // e.g. 1.5 = up to 50% extra; capped at 2
+	BurstLimit      int     `json:"burst_limit"`
+	BurstTokens     int64   `json:"burst_tokens"`
 
 	// Per-model limits
 	PerModelLimits map[string]ModelRateLimit `json:"per_model_limits"`
 }
 
+// RateLimitWindow is the state of a per-minute rate limit window after a request was admitted
+type RateLimitWindow struct {
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"` // Left in this window, excluding burst credits
+	Reset     time.Time `json:"reset"`
+	Borrowed  int       `json:"borrowed"` // Burst credits borrowed in this window, repaid in the next
+	Payback   int       `json:"payback"`  // Credits borrowed in the previous window, repaid in this one
+}
+
+// RateLimitState reports the rate limit windows a request was admitted under
+type RateLimitState struct {
+	Requests      *RateLimitWindow `json:"requests,omitempty"`
+	Tokens        *RateLimitWindow `json:"tokens,omitempty"`
+	BurstRequests int              `json:"burst_requests"` // Burst credits this request used
+	BurstTokens   int              `json:"burst_tokens"`
+	Exceeded      *RateLimitWindow `json:"-"` // The window that denied the request; nil when it was admitted
+}
+
 // ModelRateLimit defines rate limits for a specific model
 type ModelRateLimit struct {
 	ModelID           string  `json:"model_id"`
@@ -444,6 +656,15 @@ type ModelRestrictions struct {
 	AllowedProviders    []Provider `json:"allowed_providers"`
 	DefaultModel        string     `json:"default_model"`                    // Default model if not specified
 	MaxTokensPerRequest int32      `json:"max_tokens_per_request,omitempty"` // Maximum tokens per request
+
+	// Requests for a deprecated model with a replacement are sent to the
+	// replacement instead
+	RewriteDeprecatedModels bool `json:"rewrite_deprecated_models,omitempty"`
+
+	// Embeddings (/v1/embeddings)
+	AllowedEmbeddingModels []string `json:"allowed_embedding_models,omitempty"` // Empty allows any embedding model
+	MaxEmbeddingDimensions int32    `json:"max_embedding_dimensions,omitempty"` // 0 = no cap
+	MaxEmbeddingInputs     int      `json:"max_embedding_inputs,omitempty"`     // Inputs per request; 0 = no cap
 }
 
 // =============================================================================
@@ -470,6 +691,9 @@ type CachingPolicy struct {
 
 	// Cost tracking
 	TrackSavings bool `json:"track_savings"` // Track cost savings from cache
+
+	// Let clients skip the cache with the X-ModelGate-Cache header
+	AllowBypass bool `json:"allow_bypass"`
 }
 
 // =============================================================================
@@ -492,6 +716,11 @@ type RoutingPolicy struct {
 
 	// Override: if model explicitly specified, skip routing
 	AllowModelOverride bool `json:"allow_model_override"`
+
+	// Send requests the chosen model can't accept (images to a text-only
+	// model, tools to a model without tool use) to a capable model from the
+	// policy instead of rejecting them
+	CapabilityFallback bool `json:"capability_fallback"`
 }
 
 // RoutingStrategy defines available routing strategies
@@ -518,6 +747,14 @@ type CostRoutingConfig struct {
 type LatencyRoutingConfig struct {
 	MaxLatencyMs    int      `json:"max_latency_ms"`   // Route to providers with latency below this
 	PreferredModels []string `json:"preferred_models"` // Try these first
+
+	// Which rolling percentile to compare: "p50" (default) or "p95"
+	Percentile string `json:"percentile,omitempty"`
+	// A faster target must beat the current one by this much before routing
+	// switches to it (default 15%)
+	HysteresisPercent int `json:"hysteresis_percent,omitempty"`
+	// Targets whose recent error rate reaches this are skipped (default 0.5)
+	MaxErrorRate float64 `json:"max_error_rate,omitempty"`
 }
 
 // WeightedRoutingConfig for weighted distribution
@@ -563,6 +800,81 @@ type ResiliencePolicy struct {
 
 	// Timeout
 	RequestTimeoutMs int `json:"request_timeout_ms"` // Per-request timeout
+
+	// Context window management for requests larger than the model's context limit
+	ContextWindow ContextWindowConfig `json:"context_window"`
+
+	// Hedged streaming for latency-sensitive roles
+	Hedging HedgingConfig `json:"hedging"`
+}
+
+// HedgingConfig sends a duplicate streaming request to a secondary provider
+// when the primary hasn't started streaming in time; the first to answer wins
+// and the other is cancelled
+type HedgingConfig struct {
+	Enabled bool `json:"enabled"`
+
+	// How long to wait for the primary's first event before hedging (default: 500)
+	DelayMs int `json:"delay_ms"`
+
+	// Secondary target. Empty means the first fallback chain entry on a
+	// different provider.
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+}
+
+// ContextWindowConfig controls what happens when a request is estimated to
+// exceed the target model's context limit
+type ContextWindowConfig struct {
+	Enabled  bool            `json:"enabled"`
+	Strategy ContextStrategy `json:"strategy"` // truncate, summarize, larger_model
+
+	// Tokens kept free for the reply when the request sets no max_tokens (default: 1024)
+	ReserveOutputTokens int `json:"reserve_output_tokens"`
+
+	// Most recent messages that are never dropped or summarized (default: 4)
+	KeepRecentMessages int `json:"keep_recent_messages"`
+
+	// Model used to summarize older messages, e.g. "openai/gpt-4o-mini"
+	SummaryModel string `json:"summary_model"`
+
+	// Candidates for larger_model, in order of preference. Empty means the
+	// smallest model from the same provider whose context is large enough.
+	LargerModels []string `json:"larger_models"`
+}
+
+// ContextStrategy defines how an oversized request is made to fit
+type ContextStrategy string
+
+const (
+	ContextStrategyTruncate    ContextStrategy = "truncate"     // Drop the oldest messages
+	ContextStrategySummarize   ContextStrategy = "summarize"    // Replace the oldest messages with a summary
+	ContextStrategyLargerModel ContextStrategy = "larger_model" // Send the request to a model with a larger context
+)
+
+// ContextAction records how context window management changed a request
+type ContextAction struct {
+	Strategy           ContextStrategy `json:"strategy"`
+	ContextLimit       int             `json:"context_limit"`
+	EstimatedTokens    int             `json:"estimated_tokens"` // Before the change
+	DroppedMessages    int             `json:"dropped_messages,omitempty"`
+	SummarizedMessages int             `json:"summarized_messages,omitempty"`
+	OriginalModel      string          `json:"original_model,omitempty"` // Set when the model was switched
+	Model              string          `json:"model,omitempty"`
+}
+
+// RoutingDecision records why intelligent routing picked a model
+type RoutingDecision struct {
+	Strategy   RoutingStrategy `json:"strategy"`
+	Model      string          `json:"model"`                // provider/model that was selected
+	Reason     string          `json:"reason,omitempty"`     // Human-readable rationale
+	Percentile string          `json:"percentile,omitempty"` // Latency strategy: percentile compared
+	LatencyMs  float64         `json:"latency_ms,omitempty"` // Latency strategy: selected target's latency
+	Candidates int             `json:"candidates,omitempty"` // Targets that passed the filters
+	Sticky     bool            `json:"sticky,omitempty"`     // Reused the target the conversation is pinned to
+
+	// Conversation the target is pinned to, for releasing the pin on failure
+	AffinityKey string `json:"-"`
 }
 
 // FallbackConfig defines a fallback provider in the chain
@@ -590,6 +902,12 @@ type BudgetPolicy struct {
 	// Per-request limits
 	MaxCostPerRequest float64 `json:"max_cost_per_request"`
 
+	// Per-conversation ceilings on the tokens and cost of all of a
+	// conversation's turns (0 = unlimited). Once one is reached, further turns
+	// are refused with conversation_budget_exceeded.
+	MaxTokensPerConversation int64   `json:"max_tokens_per_conversation,omitempty"`
+	MaxCostPerConversation   float64 `json:"max_cost_per_conversation,omitempty"`
+
 	// Alert thresholds (0.0-1.0)
 	AlertThreshold    float64 `json:"alert_threshold"`    // Alert at this % of budget
 	CriticalThreshold float64 `json:"critical_threshold"` // Critical alert at this %
@@ -607,6 +925,11 @@ type BudgetPolicy struct {
 	SoftLimitBuffer  float64 `json:"soft_limit_buffer"` // Allow this % over budget
 }
 
+// HasConversationCeiling reports whether the policy caps conversations
+func (p BudgetPolicy) HasConversationCeiling() bool {
+	return p.Enabled && (p.MaxTokensPerConversation > 0 || p.MaxCostPerConversation > 0)
+}
+
 // BudgetExceededAction defines what happens when budget is exceeded
 type BudgetExceededAction string
 
@@ -693,6 +1016,36 @@ type AvailableToolRepository interface {
 	ListByCategory(tenantID, category string) ([]*AvailableTool, error)
 }
 
+// =============================================================================
+// Dashboard Permissions
+// =============================================================================
+
+// Usage permissions of the admin API. Users with only usage:read_own see the
+// requests made with their personal API keys.
+const (
+	PermissionUsageReadAll = "usage:read_all"
+	PermissionUsageReadOwn = "usage:read_own"
+)
+
+// DashboardPermissions returns the admin API permissions of a dashboard user
+// who has no dashboard role, by their user role
+func DashboardPermissions(userRole string) []string {
+	switch userRole {
+	case "admin":
+		return []string{"*"}
+	case "member":
+		return []string{
+			"models:list", "providers:read", "policies:read", "keys:read", "keys:write", "projects:read",
+			"budgets:read", PermissionUsageReadAll, "tools:read", "experiments:read", "metrics:read",
+		}
+	default:
+		return []string{
+			"models:list", "providers:read", "policies:read", "keys:read", "projects:read",
+			"budgets:read", PermissionUsageReadOwn, "tools:read", "metrics:read",
+		}
+	}
+}
+
 // =============================================================================
 // Default Roles
 // =============================================================================
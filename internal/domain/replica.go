@@ -0,0 +1,11 @@
+package domain
+
+import "time"
+
+// ReplicaState is the health of a database read replica as of its last check
+type ReplicaState struct {
+	Name   string        `json:"name"`
+	Usable bool          `json:"usable"` // Answering and within max_lag, so analytics reads use it
+	Lag    time.Duration `json:"lag"`
+	Error  string        `json:"error,omitempty"`
+}
@@ -25,6 +25,26 @@ type ResponseSchema struct {
 	Strict      bool                   `json:"strict,omitempty"`
 }
 
+// Response format types accepted in a chat completion's response_format
+const (
+	ResponseFormatText       = "text"
+	ResponseFormatJSONObject = "json_object"
+	ResponseFormatJSONSchema = "json_schema"
+	ResponseFormatGrammar    = "grammar" // GBNF grammar, for providers where Provider.SupportsGrammar
+)
+
+// ResponseFormat is the OpenAI-style response_format of a chat completion request
+type ResponseFormat struct {
+	Type       string          `json:"type"`
+	JSONSchema *ResponseSchema `json:"json_schema,omitempty"` // Set for json_schema
+	Grammar    string          `json:"grammar,omitempty"`     // Set for grammar
+}
+
+// Structured reports whether the format asks for JSON output
+func (f *ResponseFormat) Structured() bool {
+	return f != nil && (f.Type == ResponseFormatJSONObject || f.Type == ResponseFormatJSONSchema)
+}
+
 // StructuredResponse represents a structured output response from /v1/responses endpoint
 type StructuredResponse struct {
 	ID       string                 `json:"id"`
@@ -39,9 +59,12 @@ type StructuredResponse struct {
 // ResponseMetadata contains additional info about how the response was generated
 type ResponseMetadata struct {
 	Provider           string `json:"provider"`
-	ImplementationMode string `json:"implementation_mode"` // "native", "json_mode", "prompt_based"
+	ImplementationMode string `json:"implementation_mode"` // "native", "json_mode", "grammar", "prompt_based"
 	SchemaValidated    bool   `json:"schema_validated"`
 	RetryCount         int    `json:"retry_count,omitempty"`
+
+	ValidationFailures []string `json:"validation_failures,omitempty"` // Why each rejected attempt failed validation
+	RepairModel        string   `json:"repair_model,omitempty"`        // Set when the last repair attempt switched to the repair model
 }
 
 // ResponseUsage represents token usage for responses API
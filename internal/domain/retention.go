@@ -0,0 +1,56 @@
+// Package domain defines data retention domain types.
+package domain
+
+import "time"
+
+// Tables that support retention policies
+const (
+	RetentionTableUsageRecords = "usage_records"
+	RetentionTableAuditLogs    = "audit_logs"
+)
+
+// RetentionTables lists every table the retention janitor can manage
+var RetentionTables = []string{RetentionTableUsageRecords, RetentionTableAuditLogs}
+
+// IsRetentionTable reports whether a table supports retention policies
+func IsRetentionTable(table string) bool {
+	for _, t := range RetentionTables {
+		if t == table {
+			return true
+		}
+	}
+	return false
+}
+
+// RetentionPolicy controls how long rows in a table are kept. Rows older than
+// RetentionDays are soft-deleted (after being archived, if enabled) and purged
+// once the soft-delete grace period has passed.
+type RetentionPolicy struct {
+	Table               string     `json:"table"`
+	RetentionDays       int        `json:"retention_days"`
+	ArchiveBeforeDelete bool       `json:"archive_before_delete"`
+	Enabled             bool       `json:"enabled"`
+	LastRunAt           *time.Time `json:"last_run_at,omitempty"`
+	LastArchivedCount   int64      `json:"last_archived_count"`
+	LastDeletedCount    int64      `json:"last_deleted_count"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+// RetentionOverride is the stored state for a table: settings that override the
+// config defaults (nil means not overridden) and the result of the last run
+type RetentionOverride struct {
+	Table               string
+	RetentionDays       *int
+	ArchiveBeforeDelete *bool
+	Enabled             *bool
+	LastRunAt           *time.Time
+	LastArchivedCount   int64
+	LastDeletedCount    int64
+	UpdatedAt           time.Time
+}
+
+// ExpiredRow is a row selected for deletion, serialized as JSON for archiving
+type ExpiredRow struct {
+	ID   string
+	Data []byte
+}
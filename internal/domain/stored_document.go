@@ -0,0 +1,56 @@
+// Package domain defines stored document types.
+package domain
+
+import "time"
+
+// DocumentStatus is the processing state of a stored document
+type DocumentStatus string
+
+const (
+	DocumentStatusProcessing DocumentStatus = "processing" // Text is being extracted and embedded
+	DocumentStatusReady      DocumentStatus = "ready"      // Chunks can be retrieved
+	DocumentStatusFailed     DocumentStatus = "failed"     // See Error
+)
+
+// StoredDocument is a file ingested into the tenant's document store, whose
+// chunks ground chat requests that reference it
+type StoredDocument struct {
+	ID         string         `json:"id"`
+	Collection string         `json:"collection"`
+	Filename   string         `json:"filename"`
+	MimeType   string         `json:"mime_type"`
+	Bytes      int64          `json:"bytes"`
+	Status     DocumentStatus `json:"status"`
+	Error      string         `json:"error,omitempty"`
+	Pages      int            `json:"pages"`
+	OCRPages   int            `json:"ocr_pages"` // Pages or images transcribed by the OCR model
+	Chunks     int            `json:"chunks"`
+	APIKeyID   string         `json:"api_key_id,omitempty"` // Uploading key; empty for admin uploads
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+}
+
+// DocumentChunk is a piece of a stored document's text, embedded on its own
+type DocumentChunk struct {
+	ID         string `json:"id"`
+	DocumentID string `json:"document_id"`
+	Index      int    `json:"index"`
+	Page       int    `json:"page"` // 1-based page the chunk starts on, 0 when the file has no pages
+	Text       string `json:"text"`
+}
+
+// DocumentChunkMatch is a chunk retrieved for a question
+type DocumentChunkMatch struct {
+	Chunk      DocumentChunk `json:"chunk"`
+	Filename   string        `json:"filename"`
+	Collection string        `json:"collection"`
+	Score      float64       `json:"score"` // Cosine similarity to the question
+}
+
+// DocumentSearch selects the chunks a question is matched against
+type DocumentSearch struct {
+	DocumentIDs []string // Chunks of these documents...
+	Collections []string // ...or of the documents in these collections
+	APIKeyID    string   // Only documents of this key and admin uploads; empty searches every document
+	Limit       int
+}
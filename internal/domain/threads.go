@@ -0,0 +1,26 @@
+// Package domain defines conversation thread domain types.
+package domain
+
+import "time"
+
+// Thread is a stored conversation owned by an API key. Chat completions that
+// reference a thread are sent with its history, so clients only send new turns.
+type Thread struct {
+	ID           string            `json:"id"`
+	APIKeyID     string            `json:"api_key_id,omitempty"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+	MessageCount int               `json:"message_count"`
+	SizeBytes    int64             `json:"size_bytes"` // Total size of the stored messages
+	ExpiresAt    time.Time         `json:"expires_at"` // Pushed back every time a message is added
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+}
+
+// ThreadMessage is one message in a thread, in the order it was added
+type ThreadMessage struct {
+	ID        string    `json:"id"`
+	ThreadID  string    `json:"thread_id"`
+	Seq       int       `json:"seq"`
+	Message   Message   `json:"message"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,28 @@
+// Package domain defines two-factor authentication domain types.
+package domain
+
+import "time"
+
+// TwoFactorSettings are the tenant's two-factor requirements
+type TwoFactorSettings struct {
+	RequireForAdmins bool      `json:"require_for_admins"` // Admins can't sign in until they enroll
+	RequireForAll    bool      `json:"require_for_all"`    // Nobody can sign in until they enroll
+	UpdatedBy        string    `json:"updated_by,omitempty"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// Requires reports whether users of a role must use two-factor authentication
+func (s TwoFactorSettings) Requires(role string) bool {
+	return s.RequireForAll || s.RequireForAdmins && role == "admin"
+}
+
+// UserTwoFactor is a user's TOTP enrollment
+type UserTwoFactor struct {
+	UserID          string     `json:"user_id"`
+	Secret          string     `json:"-"`       // Base32 TOTP secret
+	Enabled         bool       `json:"enabled"` // False until the first code is confirmed
+	ConfirmedAt     *time.Time `json:"confirmed_at,omitempty"`
+	LastUsedStep    int64      `json:"-"` // Time step of the last accepted code, which can't be used again
+	BackupCodesLeft int        `json:"backup_codes_left"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
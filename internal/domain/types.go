@@ -24,6 +24,12 @@ const (
 	ProviderMistral     Provider = "mistral"
 	ProviderTogether    Provider = "together"
 	ProviderCohere      Provider = "cohere"
+	ProviderOpenRouter  Provider = "openrouter"
+	ProviderXAI         Provider = "xai"
+	ProviderDeepSeek    Provider = "deepseek"
+	ProviderHuggingFace Provider = "huggingface"
+	ProviderFireworks   Provider = "fireworks"
+	ProviderCustom      Provider = "custom" // Admin-registered OpenAI-compatible endpoints
 )
 
 // AllProviders returns all supported providers
@@ -39,6 +45,12 @@ func AllProviders() []Provider {
 		ProviderMistral,
 		ProviderTogether,
 		ProviderCohere,
+		ProviderOpenRouter,
+		ProviderXAI,
+		ProviderDeepSeek,
+		ProviderHuggingFace,
+		ProviderFireworks,
+		ProviderCustom,
 	}
 }
 
@@ -65,11 +77,35 @@ func ParseProvider(s string) (Provider, bool) {
 		return ProviderTogether, true
 	case "cohere":
 		return ProviderCohere, true
+	case "openrouter":
+		return ProviderOpenRouter, true
+	case "xai", "x-ai", "grok":
+		return ProviderXAI, true
+	case "deepseek":
+		return ProviderDeepSeek, true
+	case "huggingface", "hf":
+		return ProviderHuggingFace, true
+	case "fireworks", "fireworks_ai", "fireworksai":
+		return ProviderFireworks, true
+	case "custom", "openai_compatible", "openai-compatible":
+		return ProviderCustom, true
 	default:
 		return "", false
 	}
 }
 
+// RequiresAPIKey reports whether the provider can't be called without an API
+// key. Self-hosted backends often run without one.
+func (p Provider) RequiresAPIKey() bool {
+	return p != ProviderOllama && p != ProviderCustom
+}
+
+// SupportsGrammar reports whether the provider constrains output to a GBNF
+// grammar given as a grammar response_format
+func (p Provider) SupportsGrammar() bool {
+	return p == ProviderFireworks
+}
+
 // =============================================================================
 // Model Types
 // =============================================================================
@@ -81,35 +117,78 @@ type ModelInfo struct {
 	Provider          Provider `json:"provider" yaml:"provider"`
 	SupportsTools     bool     `json:"supports_tools" yaml:"supports_tools"`
 	SupportsReasoning bool     `json:"supports_reasoning" yaml:"supports_reasoning"`
+	SupportsVision    bool     `json:"supports_vision,omitempty" yaml:"supports_vision,omitempty"`
 	ContextLimit      uint32   `json:"context_limit" yaml:"context_limit"`
 	OutputLimit       uint32   `json:"output_limit" yaml:"output_limit"`
 	InputCostPer1M    float64  `json:"input_cost_per_1m" yaml:"input_cost_per_1m"`
 	OutputCostPer1M   float64  `json:"output_cost_per_1m" yaml:"output_cost_per_1m"`
-	Enabled           bool     `json:"enabled" yaml:"enabled"`
+	// CachedInputCostPer1M is the price of prompt tokens served from the
+	// provider's context cache (0 when the provider has no cache discount)
+	CachedInputCostPer1M float64 `json:"cached_input_cost_per_1m,omitempty" yaml:"cached_input_cost_per_1m,omitempty"`
+	Enabled              bool    `json:"enabled" yaml:"enabled"`
 	// NativeModelID is the full provider-specific model ID for API calls.
 	// For Bedrock, this is the full inference profile ID (e.g., "us.anthropic.claude-3-5-sonnet-20241022-v2:0").
 	// For other providers, this may be the same as ID or empty.
 	NativeModelID string `json:"native_model_id,omitempty" yaml:"native_model_id,omitempty"`
 }
 
+// ModelCapabilities is what a model is known to accept. A nil flag means
+// unknown: requests are only refused for capabilities known to be missing.
+type ModelCapabilities struct {
+	Tools        *bool
+	Vision       *bool
+	Reasoning    *bool
+	Grammar      *bool
+	ContextLimit int // 0 when unknown
+}
+
+// ModelFilter narrows the model catalog. Zero fields match any model.
+type ModelFilter struct {
+	Provider          Provider
+	SupportsTools     bool
+	SupportsVision    bool
+	SupportsReasoning bool
+	MinContext        uint32   // Smallest context window, in tokens
+	MaxInputCost      *float64 // Highest input price per 1M tokens
+	MaxOutputCost     *float64 // Highest output price per 1M tokens
+}
+
+// Matches reports whether a model satisfies every criterion of the filter
+func (f *ModelFilter) Matches(m ModelInfo) bool {
+	switch {
+	case f.Provider != "" && m.Provider != f.Provider,
+		f.SupportsTools && !m.SupportsTools,
+		f.SupportsVision && !m.SupportsVision,
+		f.SupportsReasoning && !m.SupportsReasoning,
+		f.MinContext > 0 && m.ContextLimit < f.MinContext,
+		f.MaxInputCost != nil && m.InputCostPer1M > *f.MaxInputCost,
+		f.MaxOutputCost != nil && m.OutputCostPer1M > *f.MaxOutputCost:
+		return false
+	}
+	return true
+}
+
 // =============================================================================
 // Chat Types
 // =============================================================================
 
 // ChatRequest represents a chat completion request
 type ChatRequest struct {
-	Model            string           `json:"model"`
-	Prompt           string           `json:"prompt"`
-	Messages         []Message        `json:"messages"`
-	SystemPrompt     string           `json:"system_prompt,omitempty"`
-	Temperature      *float32         `json:"temperature,omitempty"`
-	MaxTokens        *int32           `json:"max_tokens,omitempty"`
-	Tools            []Tool           `json:"tools,omitempty"`
-	ToolChoice       *ToolChoice      `json:"tool_choice,omitempty"`
-	ReasoningConfig  *ReasoningConfig `json:"reasoning_config,omitempty"`
-	Documents        []Document       `json:"documents,omitempty"`
-	AdditionalParams map[string]any   `json:"additional_params,omitempty"`
-	Streaming        bool             `json:"stream,omitempty"` // Whether to stream the response
+	Model             string           `json:"model"`
+	Prompt            string           `json:"prompt"`
+	Messages          []Message        `json:"messages"`
+	SystemPrompt      string           `json:"system_prompt,omitempty"`
+	Temperature       *float32         `json:"temperature,omitempty"`
+	TopP              *float32         `json:"top_p,omitempty"`
+	MaxTokens         *int32           `json:"max_tokens,omitempty"`
+	Tools             []Tool           `json:"tools,omitempty"`
+	ToolChoice        *ToolChoice      `json:"tool_choice,omitempty"`
+	ParallelToolCalls *bool            `json:"parallel_tool_calls,omitempty"` // Several tool calls per turn; nil leaves the provider default
+	ReasoningConfig   *ReasoningConfig `json:"reasoning_config,omitempty"`
+	Documents         []Document       `json:"documents,omitempty"`
+	AdditionalParams  map[string]any   `json:"additional_params,omitempty"`
+	ResponseFormat    *ResponseFormat  `json:"response_format,omitempty"`
+	Streaming         bool             `json:"stream,omitempty"` // Whether to stream the response
 
 	// Request context
 	RequestID string `json:"request_id,omitempty"`
@@ -118,8 +197,103 @@ type ChatRequest struct {
 	APIKeyID string `json:"api_key_id,omitempty"`
 	RoleID   string `json:"role_id,omitempty"`  // Single role (if API key assigned to a role)
 	GroupID  string `json:"group_id,omitempty"` // Group (if API key assigned to a group)
+
+	// Project the API key belongs to, for its budget and usage rollups
+	ProjectID string `json:"project_id,omitempty"`
+
+	// Address of the calling client, for source:IP policy conditions
+	ClientIP string `json:"-"`
+
+	// Rate limit state set by policy enforcement (for response headers and usage analytics)
+	RateLimit *RateLimitState `json:"-"`
+
+	// Set by streaming output moderation when generated content violated policy
+	OutputViolation *OutputViolation `json:"-"`
+
+	// Set when the role's output transform policy changed the response text
+	OutputTransform *OutputTransform `json:"-"`
+
+	// Set when context window management truncated, summarized or rerouted the request
+	ContextAction *ContextAction `json:"-"`
+
+	// Set when intelligent routing selected the model
+	RoutingDecision *RoutingDecision `json:"-"`
+
+	// Client's conversation ID (X-ModelGate-Conversation-ID header or thread),
+	// which sticky routing pins to one target
+	ConversationID string `json:"-"`
+
+	// Set when the role caps conversation spend: the key the request's usage
+	// is added to the conversation's total under
+	ConversationKey string `json:"-"`
+
+	// Set when the request replays a logged one (the original usage record ID)
+	ReplayOf string `json:"-"`
+
+	// Prompt tokens the provider served from its context cache, for usage records
+	CachedPromptTokens int32 `json:"-"`
+
+	// Regional deployment of the provider that served the request, when the
+	// provider has several
+	ProviderRegion string `json:"-"`
+
+	// Set on streamed requests once the provider sent its first token
+	StreamTiming *StreamTiming `json:"-"`
+
+	// Semantic cache directive from the X-ModelGate-Cache header
+	CacheControl CacheControl `json:"-"`
+
+	// Set when the semantic cache was looked up for the request
+	CacheLookup *CacheLookup `json:"-"`
+
+	// Deadline and cost cap from the X-ModelGate-Timeout-Ms and
+	// X-ModelGate-Max-Cost headers; the gateway replaces them with the limits
+	// it applied after clamping them to the role's maxima
+	Limits RequestLimits `json:"-"`
+
+	// Digest of the role policies enforced on the request, signed into its
+	// response attestation
+	PolicyVersion string `json:"-"`
+
+	// Set on the usage record of an embedding request, which has no messages
+	Embedding *EmbeddingMetadata `json:"-"`
+
+	// Set when the request was assigned a variant of a running experiment
+	Experiment *ExperimentAssignment `json:"-"`
+}
+
+// RequestLimits are a request's hard deadline and cost cap. Zero means no limit.
+type RequestLimits struct {
+	Timeout    time.Duration
+	MaxCostUSD float64
+}
+
+// CacheStatus is the outcome of a semantic cache lookup
+type CacheStatus string
+
+const (
+	CacheStatusHit         CacheStatus = "hit"          // Same prompt as a cached request
+	CacheStatusSemanticHit CacheStatus = "semantic-hit" // Prompt similar enough to a cached request
+	CacheStatusMiss        CacheStatus = "miss"
+)
+
+// CacheLookup records how the semantic cache answered a request, for
+// response headers
+type CacheLookup struct {
+	Status     CacheStatus
+	Similarity float64   // Semantic hits: similarity to the cached prompt
+	StoredAt   time.Time // Hits: when the cached response was stored
 }
 
+// CacheControl asks the gateway to skip the semantic cache for one request.
+// It is honored only when the role's caching policy allows bypass.
+type CacheControl string
+
+const (
+	CacheControlBypass  CacheControl = "bypass"  // Skip the lookup and don't store the response
+	CacheControlRefresh CacheControl = "refresh" // Skip the lookup and replace the cached response
+)
+
 // Message represents a chat message
 type Message struct {
 	Role           string          `json:"role"`
@@ -131,12 +305,20 @@ type Message struct {
 
 // ContentBlock represents a content block in a message
 type ContentBlock struct {
-	Type       string      `json:"type"` // "text", "image", "tool_result"
+	Type       string      `json:"type"` // "text", "image", "file", "tool_result"
 	Text       string      `json:"text,omitempty"`
 	ImageURL   string      `json:"image_url,omitempty"`
 	ImageData  []byte      `json:"image_data,omitempty"`
 	MediaType  string      `json:"media_type,omitempty"`
 	ToolResult *ToolResult `json:"tool_result,omitempty"`
+
+	// File blocks reference a file uploaded via /v1/files. Before the request
+	// is sent, the gateway fills in either the content or, for files passed
+	// through to the provider, the provider's reference.
+	FileID         string `json:"file_id,omitempty"`
+	Filename       string `json:"filename,omitempty"`
+	FileData       []byte `json:"file_data,omitempty"`
+	ProviderFileID string `json:"provider_file_id,omitempty"`
 }
 
 // Tool represents a tool/function definition
@@ -167,7 +349,8 @@ type FunctionCall struct {
 
 // ToolChoice controls how tools are selected
 type ToolChoice struct {
-	Mode string `json:"mode"` // "auto", "required", "none"
+	Mode     string `json:"mode"`               // "auto", "required", "none"
+	Function string `json:"function,omitempty"` // With "required", the one function the model must call
 }
 
 // ToolResult represents the result of a tool call
@@ -205,6 +388,22 @@ type Document struct {
 	ID              string            `json:"id"`
 	Text            string            `json:"text"`
 	AdditionalProps map[string]string `json:"additional_props,omitempty"`
+	Collection      string            `json:"collection,omitempty"` // With no Text, retrieve from this stored collection
+}
+
+// Reference reports whether d names stored documents to retrieve from rather
+// than carrying its text
+func (d Document) Reference() bool {
+	return d.Text == "" && (d.ID != "" || d.Collection != "")
+}
+
+// Citation ties a span of the response content to the documents or tool
+// results supporting it
+type Citation struct {
+	Start   int      `json:"start"` // Offset of the span in the content, in characters
+	End     int      `json:"end"`
+	Text    string   `json:"text"`
+	Sources []string `json:"sources"` // IDs of the documents or tool calls cited
 }
 
 // =============================================================================
@@ -237,27 +436,44 @@ type ThinkingSignatureChunk struct {
 
 func (ThinkingSignatureChunk) eventType() string { return "thinking_signature" }
 
-// ToolCallEvent is a complete tool call event
+// ToolCallEvent is a complete tool call event. Providers that stream tool
+// calls in fragments send ToolCallDeltas first and the complete call once it
+// is finished, with Streamed set.
 type ToolCallEvent struct {
 	ToolCall ToolCall `json:"tool_call"`
+	Streamed bool     `json:"streamed,omitempty"` // Already sent as ToolCallDeltas
 }
 
 func (ToolCallEvent) eventType() string { return "tool_call" }
 
-// ToolCallDelta is a partial tool call
+// ToolCallDelta is a fragment of a tool call's arguments. Fragments of one
+// call share its Index (0, 1, ... in the order calls start); the first
+// carries the call's ID and function name.
 type ToolCallDelta struct {
-	ID    string `json:"id"`
+	Index int    `json:"index"`
+	ID    string `json:"id,omitempty"`
+	Name  string `json:"name,omitempty"`
 	Delta string `json:"delta"`
 }
 
 func (ToolCallDelta) eventType() string { return "tool_call_delta" }
 
+// CitationEvent is a citation of the content streamed so far
+type CitationEvent struct {
+	Citation Citation `json:"citation"`
+}
+
+func (CitationEvent) eventType() string { return "citation" }
+
 // UsageEvent contains token usage information
 type UsageEvent struct {
 	PromptTokens     int32   `json:"prompt_tokens"`
 	CompletionTokens int32   `json:"completion_tokens"`
 	TotalTokens      int32   `json:"total_tokens"`
 	CostUSD          float64 `json:"cost_usd,omitempty"`
+	// CachedPromptTokens is the part of PromptTokens served from the
+	// provider's context cache, billed at the cached input price
+	CachedPromptTokens int32 `json:"cached_prompt_tokens,omitempty"`
 }
 
 func (UsageEvent) eventType() string { return "usage" }
@@ -299,6 +515,7 @@ type ChatResponse struct {
 	Model        string       `json:"model,omitempty"`
 	FinishReason FinishReason `json:"finish_reason,omitempty"`
 	Thinking     string       `json:"thinking,omitempty"`
+	Citations    []Citation   `json:"citations,omitempty"` // Grounding citations, from providers that return them
 	CostUSD      float64      `json:"cost_usd,omitempty"`
 	Cached       bool         `json:"cached,omitempty"`     // True if response was served from cache
 	LatencyMs    int64        `json:"latency_ms,omitempty"` // Request latency in milliseconds
@@ -451,22 +668,32 @@ type TenantSettings struct {
 	RateLimitTPM        int32      `json:"rate_limit_tpm"`
 }
 
-// RegistrationRequest represents a tenant registration request
+// Registration request statuses
+const (
+	RegistrationPending  = "pending"
+	RegistrationApproved = "approved"
+	RegistrationRejected = "rejected"
+)
+
+// RegistrationRequest is a request for an account, reviewed by an admin.
+// Approving it creates a user for AdminEmail.
 type RegistrationRequest struct {
-	ID                string    `json:"id"`
-	OrganizationName  string    `json:"organization_name"`
-	OrganizationEmail string    `json:"organization_email"`
-	AdminName         string    `json:"admin_name"`
-	AdminEmail        string    `json:"admin_email"`
-	AdminPassword     string    `json:"admin_password"`
-	Slug              string    `json:"slug"`
-	Status            string    `json:"status"` // pending, approved, rejected
-	RejectionReason   string    `json:"rejection_reason,omitempty"`
-	RequestedAt       time.Time `json:"requested_at"`
-	ReviewedAt        time.Time `json:"reviewed_at,omitempty"`
-	ReviewedBy        string    `json:"reviewed_by,omitempty"`
-	CreatedAt         time.Time `json:"created_at"`
-	UpdatedAt         time.Time `json:"updated_at"`
+	ID                string     `json:"id"`
+	OrganizationName  string     `json:"organization_name"`
+	OrganizationEmail string     `json:"organization_email"`
+	AdminName         string     `json:"admin_name"`
+	AdminEmail        string     `json:"admin_email"`
+	PasswordHash      string     `json:"-"`
+	Slug              string     `json:"slug"`
+	PreferredTier     TenantTier `json:"preferred_tier,omitempty"`
+	Status            string     `json:"status"` // pending, approved, rejected
+	RejectionReason   string     `json:"rejection_reason,omitempty"`
+	UserRole          string     `json:"user_role,omitempty"` // Role the user was created with, once approved
+	RequestedAt       time.Time  `json:"requested_at"`
+	ReviewedAt        *time.Time `json:"reviewed_at,omitempty"`
+	ReviewedBy        string     `json:"reviewed_by,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
 }
 
 // =============================================================================
@@ -503,6 +730,50 @@ func DefaultConnectionSettings() ConnectionSettings {
 	}
 }
 
+// ProviderThrottle caps the load the gateway sends to a provider, or to one of
+// its models when Model is set. Requests over a limit wait up to
+// MaxQueueWaitSec for capacity and are then rejected with a 429. Zero limits
+// are unlimited.
+type ProviderThrottle struct {
+	Model           string `json:"model,omitempty"`              // Empty for the whole provider
+	MaxConcurrent   int    `json:"max_concurrent,omitempty"`     // Requests in flight
+	TokensPerMinute int    `json:"tokens_per_minute,omitempty"`  // Prompt plus completion tokens
+	MaxQueueWaitSec int    `json:"max_queue_wait_sec,omitempty"` // 0 uses the gateway default
+}
+
+// ProviderRegion is one regional deployment of a provider, such as an Azure
+// OpenAI resource or a Bedrock region, with its own quota. Requests go to the
+// highest priority region that is up and fail over to the next one when a
+// region is down or throttled. Fields left empty use the provider's settings.
+type ProviderRegion struct {
+	Name         string `json:"name"`                    // Recorded on usage, e.g. "eastus"
+	Priority     int    `json:"priority,omitempty"`      // Lower is tried first
+	Enabled      bool   `json:"enabled"`                 // Disabled regions get no traffic
+	BaseURL      string `json:"base_url,omitempty"`      // Azure: the region's resource endpoint
+	Region       string `json:"region,omitempty"`        // Bedrock: AWS region
+	RegionPrefix string `json:"region_prefix,omitempty"` // Bedrock: inference profile prefix (us., eu.)
+	APIKeyName   string `json:"api_key_name,omitempty"`  // Provider API key for this region (Azure keys are per resource)
+}
+
+// KeyStrategy is how the gateway spreads requests across the API keys of a
+// provider within their top priority group
+type KeyStrategy string
+
+const (
+	KeyStrategyRoundRobin  KeyStrategy = "round_robin"  // Each key in turn (default)
+	KeyStrategyLeastErrors KeyStrategy = "least_errors" // The key with the lowest recent error rate
+	KeyStrategyWeighted    KeyStrategy = "weighted"     // Randomly, in proportion to key health
+)
+
+// Valid reports whether s is a known strategy; empty means round_robin
+func (s KeyStrategy) Valid() bool {
+	switch s {
+	case "", KeyStrategyRoundRobin, KeyStrategyLeastErrors, KeyStrategyWeighted:
+		return true
+	}
+	return false
+}
+
 // ProviderConfig contains credentials and settings for an LLM provider
 type ProviderConfig struct {
 	Provider Provider `json:"provider"`
@@ -527,7 +798,29 @@ type ProviderConfig struct {
 	// Connection pool settings (validated against tenant plan limits)
 	ConnectionSettings ConnectionSettings `json:"connection_settings"`
 
+	// Concurrency and TPM limits enforced by the gateway before calling the provider
+	Throttles []ProviderThrottle `json:"throttles,omitempty"`
+
+	// How requests are spread across the provider's API keys
+	KeyStrategy KeyStrategy `json:"key_strategy,omitempty"`
+
+	// Regional deployments to fail over between; none uses the settings above
+	Regions []ProviderRegion `json:"regions,omitempty"`
+	// ActiveRegion is the region a client built from this config serves
+	ActiveRegion string `json:"-"`
+
+	// Dedicated Inference Endpoints of the huggingface provider
+	HuggingFaceEndpoints []HuggingFaceEndpoint `json:"huggingface_endpoints,omitempty"`
+
+	// CustomModels are the registered models of the custom provider, loaded
+	// from custom_models rather than extra_settings
+	CustomModels []CustomModel `json:"-"`
+
 	ExtraSettings map[string]string `json:"extra_settings,omitempty"`
+
+	// APIKeyID identifies the provider_api_keys row the credentials came from, so
+	// each key gets its own client
+	APIKeyID string `json:"-"`
 }
 
 // TenantModelConfig contains tenant-specific model configuration
@@ -560,17 +853,23 @@ type APIKey struct {
 	Scopes    []string `json:"scopes"`
 	// RBAC: API key can be assigned to either a Role OR a Group (not both)
 	// If GroupID is set, the API key inherits permissions from all Roles in the Group
-	RoleID         string     `json:"role_id,omitempty"`    // Associated role for RBAC
-	RoleName       string     `json:"role_name,omitempty"`  // Role name for display
-	GroupID        string     `json:"group_id,omitempty"`   // Associated group for RBAC (alternative to role)
-	GroupName      string     `json:"group_name,omitempty"` // Group name for display
-	CreatedAt      time.Time  `json:"created_at"`
-	CreatedBy      string     `json:"created_by,omitempty"`       // User ID who created the key
-	CreatedByEmail string     `json:"created_by_email,omitempty"` // Email of creator for display
-	UpdatedAt      time.Time  `json:"updated_at,omitempty"`
-	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
-	LastUsedAt     *time.Time `json:"last_used_at,omitempty"`
-	Revoked        bool       `json:"revoked"`
+	RoleID         string        `json:"role_id,omitempty"`      // Associated role for RBAC
+	RoleName       string        `json:"role_name,omitempty"`    // Role name for display
+	GroupID        string        `json:"group_id,omitempty"`     // Associated group for RBAC (alternative to role)
+	GroupName      string        `json:"group_name,omitempty"`   // Group name for display
+	ProjectID      string        `json:"project_id,omitempty"`   // Project the key's usage is billed to
+	ProjectName    string        `json:"project_name,omitempty"` // Project name for display
+	OwnerUserID    string        `json:"owner_id,omitempty"`     // Set on personal keys, which use their owner's API role
+	OwnerEmail     string        `json:"owner_email,omitempty"`  // Owner email for display
+	CreatedAt      time.Time     `json:"created_at"`
+	CreatedBy      string        `json:"created_by,omitempty"`       // User ID who created the key
+	CreatedByEmail string        `json:"created_by_email,omitempty"` // Email of creator for display
+	UpdatedAt      time.Time     `json:"updated_at,omitempty"`
+	ExpiresAt      *time.Time    `json:"expires_at,omitempty"`
+	LastUsedAt     *time.Time    `json:"last_used_at,omitempty"`
+	Revoked        bool          `json:"revoked"`
+	AllowedCIDRs   []string      `json:"allowed_cidrs,omitempty"` // Client IP allowlist; empty = any
+	Budget         *APIKeyBudget `json:"budget,omitempty"`        // Spending caps of this key; nil = none
 }
 
 // =============================================================================
@@ -696,24 +995,55 @@ type ContentFlag struct {
 
 // UsageRecord represents a usage record
 type UsageRecord struct {
-	ID             string         `json:"id"`
-	APIKeyID       string         `json:"api_key_id,omitempty"`
-	APIKeyName     string         `json:"api_key_name,omitempty"`
-	RequestID      string         `json:"request_id"`
-	Model          string         `json:"model"`
-	Provider       Provider       `json:"provider"`
-	InputTokens    int64          `json:"input_tokens"`
-	OutputTokens   int64          `json:"output_tokens"`
-	TotalTokens    int64          `json:"total_tokens"`
-	CostUSD        float64        `json:"cost_usd"`
-	LatencyMs      int64          `json:"latency_ms"`
-	Success        bool           `json:"success"`
-	ErrorCode      string         `json:"error_code,omitempty"`
-	ErrorMessage   string         `json:"error_message,omitempty"`
-	ToolCalls      int32          `json:"tool_calls"`
-	ThinkingTokens int64          `json:"thinking_tokens,omitempty"`
-	Metadata       map[string]any `json:"metadata,omitempty"`
-	Timestamp      time.Time      `json:"timestamp"`
+	ID             string   `json:"id"`
+	APIKeyID       string   `json:"api_key_id,omitempty"`
+	APIKeyName     string   `json:"api_key_name,omitempty"`
+	UserID         string   `json:"user_id,omitempty"` // Owner of the personal API key, filled in on read
+	ProjectID      string   `json:"project_id,omitempty"`
+	RequestID      string   `json:"request_id"`
+	Model          string   `json:"model"`
+	Provider       Provider `json:"provider"`
+	InputTokens    int64    `json:"input_tokens"`
+	OutputTokens   int64    `json:"output_tokens"`
+	TotalTokens    int64    `json:"total_tokens"`
+	CostUSD        float64  `json:"cost_usd"`
+	LatencyMs      int64    `json:"latency_ms"`
+	Success        bool     `json:"success"`
+	ErrorCode      string   `json:"error_code,omitempty"`
+	ErrorMessage   string   `json:"error_message,omitempty"`
+	ToolCalls      int32    `json:"tool_calls"`
+	ThinkingTokens int64    `json:"thinking_tokens,omitempty"`
+	// Input tokens served from the provider's context cache (part of InputTokens)
+	CachedInputTokens int64 `json:"cached_input_tokens,omitempty"`
+	// Regional deployment that served the request, for multi-region providers
+	ProviderRegion string `json:"provider_region,omitempty"`
+	// The client disconnected before the response was complete
+	CancelledByClient bool `json:"cancelled_by_client,omitempty"`
+	// Streamed requests only: time from the request to the first token, and
+	// output tokens per second from then until the stream finished
+	TimeToFirstTokenMs int64          `json:"time_to_first_token_ms,omitempty"`
+	TokensPerSecond    float64        `json:"tokens_per_second,omitempty"`
+	Metadata           map[string]any `json:"metadata,omitempty"`
+	Timestamp          time.Time      `json:"timestamp"`
+
+	// Experiment and variant the request was assigned, if any
+	ExperimentID      string `json:"experiment_id,omitempty"`
+	ExperimentVariant string `json:"experiment_variant,omitempty"`
+}
+
+// StreamTiming is how quickly a streamed response was delivered
+type StreamTiming struct {
+	TimeToFirstToken time.Duration // From the request to the first text, thinking or tool call
+	TokensPerSecond  float64       // Output tokens over the time after the first token; 0 until the stream finished
+}
+
+// Finish sets the throughput of a stream that started at start and ended at
+// end with outputTokens generated
+func (t *StreamTiming) Finish(start, end time.Time, outputTokens int64) {
+	generation := end.Sub(start) - t.TimeToFirstToken
+	if outputTokens > 0 && generation > 0 {
+		t.TokensPerSecond = float64(outputTokens) / generation.Seconds()
+	}
 }
 
 // UsageStats contains aggregated usage statistics
@@ -772,6 +1102,15 @@ type APIKeyUsageStats struct {
 	CostUSD     float64 `json:"cost_usd"`
 }
 
+// UserUsageStats contains the usage of a user's personal API keys
+type UserUsageStats struct {
+	UserID      string  `json:"user_id"`
+	Email       string  `json:"email"` // Empty for deleted users
+	Requests    int64   `json:"requests"`
+	TotalTokens int64   `json:"total_tokens"`
+	CostUSD     float64 `json:"cost_usd"`
+}
+
 // UsageTimePoint is a time-series data point (alias for compatibility)
 type UsageTimePoint = UsageDataPoint
 
@@ -816,6 +1155,21 @@ type LLMClient interface {
 	SupportsModel(model string) bool
 }
 
+// StructuredOutputCapable is an optional interface for providers that enforce a
+// chat request's ResponseFormat natively. For other providers the gateway adds
+// schema instructions to the prompt and validates the output itself.
+type StructuredOutputCapable interface {
+	SupportsStructuredOutput() bool
+}
+
+// DocumentCapable is an optional interface for providers that ground answers
+// in a chat request's Documents and cite them natively. For other providers
+// the gateway adds the documents to the prompt as numbered sources and parses
+// the citations from the answer.
+type DocumentCapable interface {
+	SupportsDocuments() bool
+}
+
 // ResponsesCapable is an optional interface for providers that support native /v1/responses endpoint
 // Providers that don't implement this will fall back to prompt-based or JSON mode strategies
 type ResponsesCapable interface {
@@ -1067,23 +1421,28 @@ type BudgetAlertRepository interface {
 
 // ModelPerformance contains performance metrics for a model
 type ModelPerformance struct {
-	Model           string    `json:"model"`
-	Provider        Provider  `json:"provider"`
-	TotalRequests   int64     `json:"total_requests"`
-	SuccessfulReqs  int64     `json:"successful_requests"`
-	FailedReqs      int64     `json:"failed_requests"`
-	SuccessRate     float64   `json:"success_rate"`
-	AvgLatencyMs    float64   `json:"avg_latency_ms"`
-	P50LatencyMs    float64   `json:"p50_latency_ms"`
-	P95LatencyMs    float64   `json:"p95_latency_ms"`
-	P99LatencyMs    float64   `json:"p99_latency_ms"`
-	AvgInputTokens  float64   `json:"avg_input_tokens"`
-	AvgOutputTokens float64   `json:"avg_output_tokens"`
-	TotalCostUSD    float64   `json:"total_cost_usd"`
-	CostPerRequest  float64   `json:"cost_per_request"`
-	TokensPerSecond float64   `json:"tokens_per_second"`
-	Period          string    `json:"period"`
-	UpdatedAt       time.Time `json:"updated_at"`
+	Model           string   `json:"model"`
+	Provider        Provider `json:"provider"`
+	TotalRequests   int64    `json:"total_requests"`
+	SuccessfulReqs  int64    `json:"successful_requests"`
+	FailedReqs      int64    `json:"failed_requests"`
+	SuccessRate     float64  `json:"success_rate"`
+	AvgLatencyMs    float64  `json:"avg_latency_ms"`
+	P50LatencyMs    float64  `json:"p50_latency_ms"`
+	P95LatencyMs    float64  `json:"p95_latency_ms"`
+	P99LatencyMs    float64  `json:"p99_latency_ms"`
+	AvgInputTokens  float64  `json:"avg_input_tokens"`
+	AvgOutputTokens float64  `json:"avg_output_tokens"`
+	TotalCostUSD    float64  `json:"total_cost_usd"`
+	CostPerRequest  float64  `json:"cost_per_request"`
+	TokensPerSecond float64  `json:"tokens_per_second"`
+	// Streamed requests only
+	AvgTimeToFirstTokenMs float64   `json:"avg_time_to_first_token_ms"`
+	P50TimeToFirstTokenMs float64   `json:"p50_time_to_first_token_ms"`
+	P95TimeToFirstTokenMs float64   `json:"p95_time_to_first_token_ms"`
+	StreamedRequests      int64     `json:"streamed_requests"`
+	Period                string    `json:"period"`
+	UpdatedAt             time.Time `json:"updated_at"`
 }
 
 // ModelComparison contains a comparison between models
@@ -1111,6 +1470,14 @@ const (
 	AuditActionRevoke AuditAction = "revoke"
 	AuditActionLogin  AuditAction = "login"
 	AuditActionLogout AuditAction = "logout"
+
+	AuditActionAccessDenied AuditAction = "access_denied"
+	AuditActionExport       AuditAction = "export"
+	AuditActionReplay       AuditAction = "replay"
+	AuditActionApprove      AuditAction = "approve"
+	AuditActionReject       AuditAction = "reject"
+	AuditActionDisable      AuditAction = "disable"
+	AuditActionEnable       AuditAction = "enable"
 )
 
 // AuditResourceType represents the type of resource being audited
@@ -1125,6 +1492,29 @@ const (
 	AuditResourceProvider AuditResourceType = "provider"
 	AuditResourceTenant   AuditResourceType = "tenant"
 	AuditResourceSession  AuditResourceType = "session"
+
+	AuditResourceRetentionPolicy  AuditResourceType = "retention_policy"
+	AuditResourceFeatureFlag      AuditResourceType = "feature_flag"
+	AuditResourceConfig           AuditResourceType = "config"
+	AuditResourceModelPrice       AuditResourceType = "model_price"
+	AuditResourceAuditLog         AuditResourceType = "audit_log"
+	AuditResourceRequestLog       AuditResourceType = "request_log"
+	AuditResourceProject          AuditResourceType = "project"
+	AuditResourceCustomModel      AuditResourceType = "custom_model"
+	AuditResourceCacheEntry       AuditResourceType = "cache_entry"
+	AuditResourceRegistration     AuditResourceType = "registration"
+	AuditResourceKillSwitch       AuditResourceType = "kill_switch"
+	AuditResourceEmbedder         AuditResourceType = "embedder"
+	AuditResourceInjectionPattern AuditResourceType = "injection_pattern"
+	AuditResourceMCPToolApproval  AuditResourceType = "mcp_tool_approval"
+	AuditResourceDataPlaneAudit   AuditResourceType = "data_plane_audit"
+	AuditResourceAttestationKey   AuditResourceType = "attestation_key"
+	AuditResourceModelDeprecation AuditResourceType = "model_deprecation"
+	AuditResourceCustomDomain     AuditResourceType = "custom_domain"
+	AuditResourceTwoFactor        AuditResourceType = "two_factor"
+	AuditResourceExperiment       AuditResourceType = "experiment"
+	AuditResourceFeedback         AuditResourceType = "feedback"
+	AuditResourceFineTuneJob      AuditResourceType = "fine_tuning_job"
 )
 
 // AuditLog represents an audit log entry
@@ -1161,6 +1551,7 @@ type AuditLogFilter struct {
 	ResourceID   string
 	Action       AuditAction
 	ActorID      string
+	ActorEmail   string
 	StartTime    time.Time
 	EndTime      time.Time
 	Limit        int
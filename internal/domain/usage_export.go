@@ -0,0 +1,22 @@
+// Package domain defines scheduled usage export domain types.
+package domain
+
+import "time"
+
+// Datasets written by the usage exporter
+const (
+	UsageExportDatasetUsageRecords = "usage_records" // Token counts and cost per request
+	UsageExportDatasetRequestLogs  = "request_logs"  // Outcome, errors and metadata per request
+)
+
+// UsageExportState is the checkpoint for one exported dataset. ExportedUntil is
+// the end of the last partition written; nil means nothing was exported yet.
+type UsageExportState struct {
+	Dataset       string     `json:"dataset"`
+	ExportedUntil *time.Time `json:"exported_until,omitempty"`
+	LastRunAt     *time.Time `json:"last_run_at,omitempty"`
+	LastObjectKey string     `json:"last_object_key,omitempty"`
+	LastRowCount  int64      `json:"last_row_count"`
+	LastError     string     `json:"last_error,omitempty"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,187 @@
+// Package email sends outgoing email over SMTP.
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"modelgate/internal/config"
+)
+
+// sendTimeout bounds a send when the context has no deadline
+const sendTimeout = 30 * time.Second
+
+// Message is an email with a plain text body and an optional HTML alternative
+type Message struct {
+	To      []string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Sender sends email
+type Sender interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// SMTPSender sends email through an SMTP server
+type SMTPSender struct {
+	config config.EmailConfig
+	from   *mail.Address
+}
+
+// NewSMTPSender creates a sender from the email configuration
+func NewSMTPSender(cfg config.EmailConfig) (*SMTPSender, error) {
+	if cfg.SMTPHost == "" {
+		return nil, errors.New("email: smtp_host is required")
+	}
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("email: invalid from address %q: %w", cfg.From, err)
+	}
+	switch cfg.TLS {
+	case "", "starttls", "tls", "none":
+	default:
+		return nil, fmt.Errorf("email: unknown tls mode %q", cfg.TLS)
+	}
+	if cfg.SMTPPort == 0 {
+		cfg.SMTPPort = 587
+	}
+	return &SMTPSender{config: cfg, from: from}, nil
+}
+
+// Send delivers msg to its recipients
+func (s *SMTPSender) Send(ctx context.Context, msg *Message) error {
+	if len(msg.To) == 0 {
+		return errors.New("email: no recipients")
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, sendTimeout)
+		defer cancel()
+	}
+
+	host := s.config.SMTPHost
+	addr := net.JoinHostPort(host, strconv.Itoa(s.config.SMTPPort))
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("email: connect to %s: %w", addr, err)
+	}
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+	if s.config.TLS == "tls" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: host})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return fmt.Errorf("email: tls handshake: %w", err)
+		}
+		conn = tlsConn
+	}
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("email: %w", err)
+	}
+	defer c.Close()
+
+	if s.config.TLS == "" || s.config.TLS == "starttls" {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("email: %s does not support STARTTLS", host)
+		}
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return fmt.Errorf("email: starttls: %w", err)
+		}
+	}
+	if s.config.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.config.Username, s.config.Password, host)); err != nil {
+			return fmt.Errorf("email: auth: %w", err)
+		}
+	}
+
+	if err := c.Mail(s.from.Address); err != nil {
+		return fmt.Errorf("email: %w", err)
+	}
+	for _, to := range msg.To {
+		if err := c.Rcpt(to); err != nil {
+			return fmt.Errorf("email: recipient %s: %w", to, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("email: %w", err)
+	}
+	if _, err := w.Write(s.build(msg)); err != nil {
+		return fmt.Errorf("email: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("email: %w", err)
+	}
+	return c.Quit()
+}
+
+// build renders msg as a MIME message
+func (s *SMTPSender) build(msg *Message) []byte {
+	var b bytes.Buffer
+	header := func(k, v string) { fmt.Fprintf(&b, "%s: %s\r\n", k, v) }
+	header("From", s.from.String())
+	header("To", strings.Join(msg.To, ", "))
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", fmt.Sprintf("<%s@%s>", randomID(), messageIDDomain(s.from.Address)))
+	header("MIME-Version", "1.0")
+
+	if msg.HTML == "" {
+		writePart(&b, "text/plain", msg.Text)
+		return b.Bytes()
+	}
+	boundary := randomID()
+	header("Content-Type", fmt.Sprintf("multipart/alternative; boundary=%q", boundary))
+	b.WriteString("\r\n")
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain", msg.Text},
+		{"text/html", msg.HTML},
+	} {
+		fmt.Fprintf(&b, "--%s\r\n", boundary)
+		writePart(&b, part.contentType, part.body)
+		b.WriteString("\r\n")
+	}
+	fmt.Fprintf(&b, "--%s--\r\n", boundary)
+	return b.Bytes()
+}
+
+// writePart writes the headers and quoted-printable body of a part
+func writePart(b *bytes.Buffer, contentType, body string) {
+	fmt.Fprintf(b, "Content-Type: %s; charset=utf-8\r\n", contentType)
+	b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+	w := quotedprintable.NewWriter(b)
+	w.Write([]byte(strings.ReplaceAll(body, "\n", "\r\n")))
+	w.Close()
+}
+
+func randomID() string {
+	buf := make([]byte, 12)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+func messageIDDomain(address string) string {
+	if i := strings.LastIndex(address, "@"); i >= 0 {
+		return address[i+1:]
+	}
+	return "modelgate"
+}
@@ -0,0 +1,376 @@
+// Package embedders lets each embedding feature, the semantic cache, MCP
+// tool search and injection detection, use its own embedder: a self-hosted
+// Ollama model for one and OpenAI for another, say. Settings are stored in the database and swapped
+// in without a restart, on the instance that changed them at once and on the
+// others within refreshInterval. Features without stored settings use the
+// [embedder] section of config.toml. When a feature moves to a model whose
+// vectors aren't comparable, the vectors it stored are cleared and its
+// pgvector column resized to the new dimensions.
+package embedders
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+// Store is the persistence used by the service (implemented by postgres.TenantStore)
+type Store interface {
+	ListEmbedderSettings(ctx context.Context) ([]*domain.EmbedderSettings, error)
+	SaveEmbedderSettings(ctx context.Context, e *domain.EmbedderSettings) error
+	DeleteEmbedderSettings(ctx context.Context, feature domain.EmbedderFeature) (bool, error)
+	ResetEmbeddings(ctx context.Context, feature domain.EmbedderFeature, dimensions int) error
+	CreateAuditLog(ctx context.Context, log *domain.AuditLog) error
+}
+
+// Client embeds texts
+type Client interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// Factory creates a client for embedder settings
+type Factory func(settings domain.EmbedderSettings) (Client, error)
+
+// Binding connects a feature to the service. Apply installs the feature's
+// embedder, at start and whenever its settings change. Reset, if set, runs
+// in the background after the feature's stored vectors were cleared, to
+// compute them again.
+type Binding struct {
+	Apply func(settings domain.EmbedderSettings)
+	Reset func(ctx context.Context)
+}
+
+// refreshInterval bounds how long a change made on another instance takes to apply here
+const refreshInterval = 30 * time.Second
+
+// maxIndexedDimensions is the most dimensions pgvector's HNSW index supports
+const maxIndexedDimensions = 2000
+
+// probeText is embedded to check new settings and learn their dimensions
+const probeText = "ModelGate embedder check"
+
+// Actor is who changed an embedder, for the audit log
+type Actor struct {
+	ID        string
+	Email     string
+	IPAddress string
+	UserAgent string
+}
+
+// Service keeps each feature on its configured embedder
+type Service struct {
+	store   Store
+	factory Factory
+
+	mu       sync.Mutex
+	defaults domain.EmbedderSettings
+	stored   map[domain.EmbedderFeature]*domain.EmbedderSettings
+	applied  map[domain.EmbedderFeature]domain.EmbedderSettings
+	bindings map[domain.EmbedderFeature]Binding
+}
+
+// NewService creates a new embedder service. defaults are the config.toml
+// settings, used by features without their own.
+func NewService(store Store, defaults domain.EmbedderSettings, factory Factory) *Service {
+	return &Service{
+		store:    store,
+		factory:  factory,
+		defaults: defaults,
+		stored:   make(map[domain.EmbedderFeature]*domain.EmbedderSettings),
+		applied:  make(map[domain.EmbedderFeature]domain.EmbedderSettings),
+		bindings: make(map[domain.EmbedderFeature]Binding),
+	}
+}
+
+// Bind connects a feature. Bind every feature before Start.
+func (s *Service) Bind(feature domain.EmbedderFeature, b Binding) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bindings[feature] = b
+}
+
+// Effective returns the settings a feature uses
+func (s *Service) Effective(feature domain.EmbedderFeature) domain.EmbedderSettings {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.effective(feature)
+}
+
+// effective returns a feature's settings. Callers hold s.mu.
+func (s *Service) effective(feature domain.EmbedderFeature) domain.EmbedderSettings {
+	if e, ok := s.stored[feature]; ok {
+		return *e
+	}
+	e := s.defaults
+	e.Feature = feature
+	e.FromConfig = true
+	return e
+}
+
+// List returns the settings of every feature
+func (s *Service) List(ctx context.Context) ([]domain.EmbedderSettings, error) {
+	if err := s.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := make([]domain.EmbedderSettings, len(domain.EmbedderFeatures))
+	for i, feature := range domain.EmbedderFeatures {
+		list[i] = s.effective(feature)
+	}
+	return list, nil
+}
+
+// Set gives a feature its own embedder. The embedder must answer a test
+// embedding; its dimensions, when given, must match what it returns. If the
+// new vectors aren't comparable with the old ones, the feature's stored
+// vectors are cleared and recomputed where possible. Without an API key the
+// feature keeps the key stored for the same endpoint.
+func (s *Service) Set(ctx context.Context, settings domain.EmbedderSettings, actor Actor) (*domain.EmbedderSettings, error) {
+	if !settings.Feature.Valid() {
+		return nil, fmt.Errorf("unknown embedder feature %q", settings.Feature)
+	}
+	if settings.APIKey == "" {
+		s.mu.Lock()
+		if current, ok := s.stored[settings.Feature]; ok && current.Type == settings.Type && current.BaseURL == settings.BaseURL {
+			settings.APIKey = current.APIKey
+		}
+		s.mu.Unlock()
+	}
+	switch settings.Type {
+	case "ollama":
+	case "openai":
+		if settings.APIKey == "" && settings.BaseURL == "" {
+			return nil, fmt.Errorf("an OpenAI embedder needs an API key or a self-hosted baseUrl")
+		}
+	default:
+		return nil, fmt.Errorf("embedder type %q is not one of ollama, openai", settings.Type)
+	}
+	if settings.Model == "" {
+		return nil, fmt.Errorf("model is required")
+	}
+	if settings.Dimensions < 0 {
+		return nil, fmt.Errorf("dimensions must be positive")
+	}
+	if err := s.probe(ctx, &settings); err != nil {
+		return nil, err
+	}
+	settings.FromConfig = false
+	settings.UpdatedBy = actor.Email
+	settings.UpdatedAt = time.Now()
+	if err := s.store.SaveEmbedderSettings(ctx, &settings); err != nil {
+		return nil, fmt.Errorf("save embedder settings: %w", err)
+	}
+
+	s.mu.Lock()
+	previous := s.effective(settings.Feature)
+	stored := settings
+	s.stored[settings.Feature] = &stored
+	s.mu.Unlock()
+
+	reset, err := s.switchTo(ctx, previous, settings)
+	s.audit(ctx, domain.AuditActionUpdate, settings, actor, reset, err)
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// Clear returns a feature to the config.toml embedder
+func (s *Service) Clear(ctx context.Context, feature domain.EmbedderFeature, actor Actor) (*domain.EmbedderSettings, error) {
+	if !feature.Valid() {
+		return nil, fmt.Errorf("unknown embedder feature %q", feature)
+	}
+	s.mu.Lock()
+	previous := s.effective(feature)
+	s.mu.Unlock()
+	if previous.FromConfig {
+		return &previous, nil
+	}
+
+	s.mu.Lock()
+	next := s.defaults
+	s.mu.Unlock()
+	next.Feature, next.FromConfig = feature, true
+	if err := s.probe(ctx, &next); err != nil {
+		return nil, fmt.Errorf("config.toml embedder: %w", err)
+	}
+	if _, err := s.store.DeleteEmbedderSettings(ctx, feature); err != nil {
+		return nil, fmt.Errorf("delete embedder settings: %w", err)
+	}
+	s.mu.Lock()
+	delete(s.stored, feature)
+	s.mu.Unlock()
+
+	reset, err := s.switchTo(ctx, previous, next)
+	s.audit(ctx, domain.AuditActionDelete, next, actor, reset, err)
+	if err != nil {
+		return nil, err
+	}
+	return &next, nil
+}
+
+// SetDefaults replaces the config.toml settings after a reload. Features
+// without their own embedder switch to them; their stored vectors are kept.
+func (s *Service) SetDefaults(defaults domain.EmbedderSettings) {
+	s.mu.Lock()
+	s.defaults = defaults
+	s.mu.Unlock()
+	s.applyChanged()
+}
+
+// Refresh reloads the stored settings and swaps the embedder of any feature
+// changed on another instance
+func (s *Service) Refresh(ctx context.Context) error {
+	list, err := s.store.ListEmbedderSettings(ctx)
+	if err != nil {
+		return fmt.Errorf("list embedder settings: %w", err)
+	}
+	stored := make(map[domain.EmbedderFeature]*domain.EmbedderSettings, len(list))
+	for _, e := range list {
+		if e.Feature.Valid() {
+			stored[e.Feature] = e
+		}
+	}
+	s.mu.Lock()
+	s.stored = stored
+	s.mu.Unlock()
+	s.applyChanged()
+	return nil
+}
+
+// Start loads the stored settings, installs every feature's embedder and
+// keeps them current until ctx is done
+func (s *Service) Start(ctx context.Context) {
+	if err := s.Refresh(ctx); err != nil {
+		slog.Warn("Failed to load embedder settings, using config.toml for every feature", "error", err)
+		s.applyChanged()
+	}
+	go func() {
+		ticker := time.NewTicker(refreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.Refresh(ctx); err != nil {
+					slog.Warn("Failed to refresh embedder settings", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// applyChanged installs the embedder of each feature whose settings differ
+// from the ones it runs with
+func (s *Service) applyChanged() {
+	type change struct {
+		apply    func(domain.EmbedderSettings)
+		settings domain.EmbedderSettings
+	}
+	var changes []change
+	s.mu.Lock()
+	for feature, b := range s.bindings {
+		next := s.effective(feature)
+		if current, ok := s.applied[feature]; ok && sameClient(current, next) {
+			continue
+		}
+		s.applied[feature] = next
+		changes = append(changes, change{b.Apply, next})
+	}
+	s.mu.Unlock()
+	for _, c := range changes {
+		slog.Info("Embedder installed", "feature", c.settings.Feature, "type", c.settings.Type,
+			"model", c.settings.Model, "from_config", c.settings.FromConfig)
+		c.apply(c.settings)
+	}
+}
+
+// switchTo installs next in place of previous, first clearing the stored
+// vectors when they aren't comparable. It reports whether they were cleared.
+func (s *Service) switchTo(ctx context.Context, previous, next domain.EmbedderSettings) (bool, error) {
+	reset := !previous.SameVectors(&next)
+	if reset {
+		if err := s.store.ResetEmbeddings(ctx, next.Feature, next.Dimensions); err != nil {
+			return false, fmt.Errorf("reset %s embeddings: %w", next.Feature, err)
+		}
+		slog.Warn("Embedder changed, stored vectors cleared", "feature", next.Feature,
+			"model", next.Model, "dimensions", next.Dimensions)
+	}
+	s.applyChanged()
+
+	s.mu.Lock()
+	b := s.bindings[next.Feature]
+	s.mu.Unlock()
+	if reset && b.Reset != nil {
+		go b.Reset(context.WithoutCancel(ctx))
+	}
+	return reset, nil
+}
+
+// probe checks that an embedder answers and sets its dimensions
+func (s *Service) probe(ctx context.Context, settings *domain.EmbedderSettings) error {
+	client, err := s.factory(*settings)
+	if err != nil {
+		return err
+	}
+	if client == nil {
+		return fmt.Errorf("embedder is not configured")
+	}
+	vectors, err := client.Embed(ctx, []string{probeText})
+	if err != nil {
+		return fmt.Errorf("test embedding failed: %w", err)
+	}
+	if len(vectors) == 0 || len(vectors[0]) == 0 {
+		return fmt.Errorf("test embedding returned no vector")
+	}
+	dims := len(vectors[0])
+	if settings.Dimensions > 0 && settings.Dimensions != dims {
+		return fmt.Errorf("%s returns %d dimensions, not %d", settings.Model, dims, settings.Dimensions)
+	}
+	if dims > maxIndexedDimensions {
+		return fmt.Errorf("%s returns %d dimensions; vector indexes support at most %d", settings.Model, dims, maxIndexedDimensions)
+	}
+	settings.Dimensions = dims
+	return nil
+}
+
+// sameClient reports whether two settings build the same client
+func sameClient(a, b domain.EmbedderSettings) bool {
+	return a.Type == b.Type && a.Model == b.Model && a.BaseURL == b.BaseURL && a.APIKey == b.APIKey
+}
+
+func (s *Service) audit(ctx context.Context, action domain.AuditAction, e domain.EmbedderSettings, actor Actor, reset bool, err error) {
+	entry := &domain.AuditLog{
+		Action:       action,
+		ResourceType: domain.AuditResourceEmbedder,
+		ResourceID:   string(e.Feature),
+		ResourceName: string(e.Feature),
+		ActorID:      actor.ID,
+		ActorEmail:   actor.Email,
+		ActorType:    "admin",
+		IPAddress:    actor.IPAddress,
+		UserAgent:    actor.UserAgent,
+		NewValue: map[string]any{
+			"type":        e.Type,
+			"model":       e.Model,
+			"base_url":    e.BaseURL,
+			"dimensions":  e.Dimensions,
+			"from_config": e.FromConfig,
+		},
+		Details: map[string]any{"vectors_reset": reset},
+		Status:  "success",
+	}
+	if err != nil {
+		entry.Status = "failure"
+		entry.ErrorMessage = err.Error()
+	}
+	if err := s.store.CreateAuditLog(context.WithoutCancel(ctx), entry); err != nil {
+		slog.Error("Failed to audit embedder change", "feature", e.Feature, "error", err)
+	}
+}
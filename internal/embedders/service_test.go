@@ -0,0 +1,180 @@
+package embedders
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"modelgate/internal/domain"
+)
+
+type memStore struct {
+	settings map[domain.EmbedderFeature]*domain.EmbedderSettings
+	resets   []domain.EmbedderFeature
+	audits   []*domain.AuditLog
+}
+
+func (m *memStore) ListEmbedderSettings(ctx context.Context) ([]*domain.EmbedderSettings, error) {
+	var list []*domain.EmbedderSettings
+	for _, e := range m.settings {
+		copied := *e
+		list = append(list, &copied)
+	}
+	return list, nil
+}
+
+func (m *memStore) SaveEmbedderSettings(ctx context.Context, e *domain.EmbedderSettings) error {
+	copied := *e
+	m.settings[e.Feature] = &copied
+	return nil
+}
+
+func (m *memStore) DeleteEmbedderSettings(ctx context.Context, feature domain.EmbedderFeature) (bool, error) {
+	_, ok := m.settings[feature]
+	delete(m.settings, feature)
+	return ok, nil
+}
+
+func (m *memStore) ResetEmbeddings(ctx context.Context, feature domain.EmbedderFeature, dimensions int) error {
+	m.resets = append(m.resets, feature)
+	return nil
+}
+
+func (m *memStore) CreateAuditLog(ctx context.Context, log *domain.AuditLog) error {
+	m.audits = append(m.audits, log)
+	return nil
+}
+
+// fakeClient returns vectors whose length depends on the model
+type fakeClient struct{ dims int }
+
+func (c fakeClient) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i := range texts {
+		vectors[i] = make([]float32, c.dims)
+	}
+	return vectors, nil
+}
+
+func fakeFactory(e domain.EmbedderSettings) (Client, error) {
+	switch e.Model {
+	case "nomic-embed-text":
+		return fakeClient{dims: 768}, nil
+	case "text-embedding-3-small":
+		return fakeClient{dims: 1536}, nil
+	case "text-embedding-3-large":
+		return fakeClient{dims: 3072}, nil
+	}
+	return fakeClient{dims: 384}, nil
+}
+
+func TestEmbedders(t *testing.T) {
+	ctx := context.Background()
+	store := &memStore{settings: make(map[domain.EmbedderFeature]*domain.EmbedderSettings)}
+	svc := NewService(store, domain.EmbedderSettings{Type: "ollama", Model: "nomic-embed-text"}, fakeFactory)
+	admin := Actor{ID: "u1", Email: "admin@example.com"}
+
+	var mu sync.Mutex
+	installed := make(map[domain.EmbedderFeature]string)
+	resets := make(chan domain.EmbedderFeature, 4)
+	for _, feature := range domain.EmbedderFeatures {
+		svc.Bind(feature, Binding{
+			Apply: func(e domain.EmbedderSettings) {
+				mu.Lock()
+				installed[feature] = e.Model
+				mu.Unlock()
+			},
+			Reset: func(ctx context.Context) { resets <- feature },
+		})
+	}
+	svc.Start(ctx)
+	if installed[domain.EmbedderFeatureSemanticCache] != "nomic-embed-text" || installed[domain.EmbedderFeatureToolSearch] != "nomic-embed-text" {
+		t.Fatalf("expected every feature on the config.toml embedder, got %v", installed)
+	}
+
+	// A feature gets its own embedder; the probe learns its dimensions
+	saved, err := svc.Set(ctx, domain.EmbedderSettings{
+		Feature: domain.EmbedderFeatureToolSearch, Type: "openai", Model: "text-embedding-3-small", APIKey: "sk-test",
+	}, admin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Dimensions != 1536 || saved.FromConfig || saved.UpdatedBy != admin.Email {
+		t.Fatalf("unexpected settings %+v", saved)
+	}
+	mu.Lock()
+	if installed[domain.EmbedderFeatureToolSearch] != "text-embedding-3-small" || installed[domain.EmbedderFeatureSemanticCache] != "nomic-embed-text" {
+		t.Fatalf("expected only tool search to switch, got %v", installed)
+	}
+	mu.Unlock()
+
+	// The old vectors aren't comparable, so they're cleared and recomputed
+	if len(store.resets) != 1 || store.resets[0] != domain.EmbedderFeatureToolSearch {
+		t.Fatalf("expected the tool search vectors to be reset, got %v", store.resets)
+	}
+	if feature := <-resets; feature != domain.EmbedderFeatureToolSearch {
+		t.Fatalf("expected tool search to re-embed, got %s", feature)
+	}
+
+	// Saving the same model again keeps the vectors and the stored key
+	saved, err = svc.Set(ctx, domain.EmbedderSettings{
+		Feature: domain.EmbedderFeatureToolSearch, Type: "openai", Model: "text-embedding-3-small",
+	}, admin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.APIKey != "sk-test" || len(store.resets) != 1 {
+		t.Fatalf("expected no reset and the key kept, got %+v and %d resets", saved, len(store.resets))
+	}
+
+	// Dimensions must match the model and fit a vector index
+	if _, err := svc.Set(ctx, domain.EmbedderSettings{
+		Feature: domain.EmbedderFeatureSemanticCache, Type: "ollama", Model: "nomic-embed-text", Dimensions: 1024,
+	}, admin); err == nil {
+		t.Fatal("expected a dimension mismatch to be rejected")
+	}
+	if _, err := svc.Set(ctx, domain.EmbedderSettings{
+		Feature: domain.EmbedderFeatureSemanticCache, Type: "openai", Model: "text-embedding-3-large", APIKey: "sk-test",
+	}, admin); err == nil {
+		t.Fatal("expected more dimensions than an index supports to be rejected")
+	}
+	if _, err := svc.Set(ctx, domain.EmbedderSettings{
+		Feature: domain.EmbedderFeatureSemanticCache, Type: "openai", Model: "text-embedding-3-small",
+	}, admin); err == nil {
+		t.Fatal("expected an OpenAI embedder without a key or endpoint to be rejected")
+	}
+
+	// Clearing returns the feature to config.toml and resets its vectors again
+	settings, err := svc.Clear(ctx, domain.EmbedderFeatureToolSearch, admin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !settings.FromConfig || settings.Model != "nomic-embed-text" || len(store.resets) != 2 {
+		t.Fatalf("expected the config.toml embedder after a reset, got %+v and %d resets", settings, len(store.resets))
+	}
+	<-resets
+	if len(store.settings) != 0 {
+		t.Fatalf("expected no stored settings, got %v", store.settings)
+	}
+
+	// Settings saved on another instance apply on refresh
+	store.settings[domain.EmbedderFeatureSemanticCache] = &domain.EmbedderSettings{
+		Feature: domain.EmbedderFeatureSemanticCache, Type: "ollama", Model: "all-minilm", Dimensions: 384,
+	}
+	if err := svc.Refresh(ctx); err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	if installed[domain.EmbedderFeatureSemanticCache] != "all-minilm" {
+		t.Fatalf("expected the refreshed embedder, got %v", installed)
+	}
+	mu.Unlock()
+
+	// Every change is audited
+	if len(store.audits) != 3 {
+		t.Fatalf("expected 3 audit entries, got %d", len(store.audits))
+	}
+	if store.audits[2].Action != domain.AuditActionDelete {
+		t.Fatalf("expected the clear to be audited as a delete, got %s", store.audits[2].Action)
+	}
+}
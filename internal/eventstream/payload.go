@@ -0,0 +1,163 @@
+package eventstream
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"modelgate/internal/domain"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// SchemaVersion is the version of the event payloads below. Fields may be
+// added within a version; renaming or removing one needs a new version.
+const SchemaVersion = 1
+
+// UsageEventV1 is the data of a usage event
+type UsageEventV1 struct {
+	ID                 string   `json:"id"`
+	RequestID          string   `json:"request_id"`
+	APIKeyID           string   `json:"api_key_id"`
+	ProjectID          string   `json:"project_id"`
+	UserID             string   `json:"user_id"`
+	Model              string   `json:"model"`
+	Provider           string   `json:"provider"`
+	ProviderRegion     string   `json:"provider_region"`
+	InputTokens        int64    `json:"input_tokens"`
+	CachedInputTokens  int64    `json:"cached_input_tokens"`
+	OutputTokens       int64    `json:"output_tokens"`
+	ThinkingTokens     int64    `json:"thinking_tokens"`
+	TotalTokens        int64    `json:"total_tokens"`
+	CostUSD            float64  `json:"cost_usd"`
+	LatencyMs          int64    `json:"latency_ms"`
+	TimeToFirstTokenMs *int64   `json:"time_to_first_token_ms"`
+	TokensPerSecond    *float64 `json:"tokens_per_second"`
+	IsSuccess          bool     `json:"is_success"`
+	ErrorCode          string   `json:"error_code"`
+	ToolCalls          int      `json:"tool_calls"`
+	IsCached           bool     `json:"is_cached"`
+	CancelledByClient  bool     `json:"cancelled_by_client"`
+	CreatedAt          string   `json:"created_at"`
+}
+
+// PolicyViolationEventV1 is the data of a policy violation event
+type PolicyViolationEventV1 struct {
+	ID            string          `json:"id"`
+	APIKeyID      string          `json:"api_key_id"`
+	PolicyID      string          `json:"policy_id"`
+	PolicyName    string          `json:"policy_name"`
+	ViolationType string          `json:"violation_type"`
+	Severity      int             `json:"severity"`
+	Message       string          `json:"message"`
+	Metadata      json.RawMessage `json:"metadata"`
+	Timestamp     string          `json:"timestamp"`
+}
+
+// AuditEventV1 is the data of an audit event
+type AuditEventV1 struct {
+	ID           string          `json:"id"`
+	Timestamp    string          `json:"timestamp"`
+	Action       string          `json:"action"`
+	ResourceType string          `json:"resource_type"`
+	ResourceID   string          `json:"resource_id"`
+	ResourceName string          `json:"resource_name"`
+	ActorID      string          `json:"actor_id"`
+	ActorEmail   string          `json:"actor_email"`
+	ActorType    string          `json:"actor_type"`
+	IPAddress    string          `json:"ip_address"`
+	UserAgent    string          `json:"user_agent"`
+	Details      json.RawMessage `json:"details"`
+	OldValue     json.RawMessage `json:"old_value"`
+	NewValue     json.RawMessage `json:"new_value"`
+	Status       string          `json:"status"`
+	ErrorMessage string          `json:"error_message"`
+}
+
+// Envelope wraps the data of every event
+type Envelope struct {
+	ID            string                 `json:"id"` // ID of the source row; the same on redelivery
+	Type          domain.StreamEventType `json:"type"`
+	SchemaVersion int                    `json:"schema_version"`
+	Time          time.Time              `json:"time"`
+	Data          any                    `json:"data"`
+}
+
+// newEnvelope decodes the row of an outbox event into its versioned payload.
+// Columns the version doesn't know are left out.
+func newEnvelope(event domain.OutboxEvent) (*Envelope, error) {
+	var data any
+	var id string
+	switch event.Type {
+	case domain.StreamEventUsage:
+		var d UsageEventV1
+		if err := json.Unmarshal(event.Payload, &d); err != nil {
+			return nil, err
+		}
+		data, id = d, d.ID
+	case domain.StreamEventPolicyViolation:
+		var d PolicyViolationEventV1
+		if err := json.Unmarshal(event.Payload, &d); err != nil {
+			return nil, err
+		}
+		data, id = d, d.ID
+	case domain.StreamEventAudit:
+		var d AuditEventV1
+		if err := json.Unmarshal(event.Payload, &d); err != nil {
+			return nil, err
+		}
+		data, id = d, d.ID
+	default:
+		return nil, fmt.Errorf("unknown event type %q", event.Type)
+	}
+	if id == "" {
+		id = fmt.Sprintf("outbox-%d", event.ID)
+	}
+	return &Envelope{ID: id, Type: event.Type, SchemaVersion: SchemaVersion, Time: event.CreatedAt.UTC(), Data: data}, nil
+}
+
+// encodeJSON encodes an envelope as JSON
+func encodeJSON(env *Envelope) ([]byte, error) {
+	return json.Marshal(env)
+}
+
+// encodeProtobuf encodes an envelope as the modelgate.events.v1.Event message
+// of event.proto, with the data as a google.protobuf.Struct
+func encodeProtobuf(env *Envelope) ([]byte, error) {
+	raw, err := json.Marshal(env.Data)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	data, err := structpb.NewStruct(fields)
+	if err != nil {
+		return nil, err
+	}
+	dataBytes, err := proto.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	timeBytes, err := proto.Marshal(timestamppb.New(env.Time))
+	if err != nil {
+		return nil, err
+	}
+
+	var b []byte
+	b = protowire.AppendTag(b, 1, protowire.BytesType)
+	b = protowire.AppendString(b, env.ID)
+	b = protowire.AppendTag(b, 2, protowire.BytesType)
+	b = protowire.AppendString(b, string(env.Type))
+	b = protowire.AppendTag(b, 3, protowire.VarintType)
+	b = protowire.AppendVarint(b, uint64(env.SchemaVersion))
+	b = protowire.AppendTag(b, 4, protowire.BytesType)
+	b = protowire.AppendBytes(b, timeBytes)
+	b = protowire.AppendTag(b, 5, protowire.BytesType)
+	b = protowire.AppendBytes(b, dataBytes)
+	return b, nil
+}
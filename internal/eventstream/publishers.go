@@ -0,0 +1,98 @@
+package eventstream
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/segmentio/kafka-go"
+)
+
+// Message is an encoded event ready to publish
+type Message struct {
+	Topic   string // Kafka topic or NATS subject
+	Key     string // Event ID
+	Value   []byte
+	Headers map[string]string
+}
+
+// Publisher sends messages to a broker. Publish returns once the broker
+// acknowledged every message, or with an error if any may not have been.
+type Publisher interface {
+	Publish(ctx context.Context, msgs []Message) error
+	Close() error
+}
+
+// kafkaPublisher publishes to Kafka, waiting for every in-sync replica. The
+// event ID is the message key, so a redelivered event lands on the same
+// partition as the first copy.
+type kafkaPublisher struct {
+	writer *kafka.Writer
+}
+
+func newKafkaPublisher(brokers []string) *kafkaPublisher {
+	return &kafkaPublisher{writer: &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+		BatchTimeout: 10 * time.Millisecond,
+		WriteTimeout: 30 * time.Second,
+	}}
+}
+
+func (p *kafkaPublisher) Publish(ctx context.Context, msgs []Message) error {
+	out := make([]kafka.Message, len(msgs))
+	for i, m := range msgs {
+		out[i] = kafka.Message{Topic: m.Topic, Key: []byte(m.Key), Value: m.Value}
+		for k, v := range m.Headers {
+			out[i].Headers = append(out[i].Headers, kafka.Header{Key: k, Value: []byte(v)})
+		}
+	}
+	return p.writer.WriteMessages(ctx, out...)
+}
+
+func (p *kafkaPublisher) Close() error {
+	return p.writer.Close()
+}
+
+// natsPublisher publishes to NATS JetStream. The event ID is the message ID,
+// so the stream drops redeliveries within its duplicate window.
+type natsPublisher struct {
+	conn *nats.Conn
+	js   jetstream.JetStream
+}
+
+func newNATSPublisher(url string) (*natsPublisher, error) {
+	// Keep trying in the background when NATS is down at startup; events
+	// wait in the outbox meanwhile
+	conn, err := nats.Connect(url, nats.Name("modelgate"), nats.RetryOnFailedConnect(true), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, fmt.Errorf("connect to NATS: %w", err)
+	}
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &natsPublisher{conn: conn, js: js}, nil
+}
+
+func (p *natsPublisher) Publish(ctx context.Context, msgs []Message) error {
+	for _, m := range msgs {
+		msg := nats.NewMsg(m.Topic)
+		msg.Data = m.Value
+		for k, v := range m.Headers {
+			msg.Header.Set(k, v)
+		}
+		if _, err := p.js.PublishMsg(ctx, msg, jetstream.WithMsgID(m.Key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *natsPublisher) Close() error {
+	return p.conn.Drain()
+}
@@ -0,0 +1,196 @@
+// Package eventstream publishes usage records, policy violations and audit
+// events to Kafka or NATS. Database triggers copy each new row into the
+// event_outbox table in the transaction that inserted it; the service
+// publishes the outbox oldest first and marks events published once the
+// broker acknowledged them. Delivery is at least once: consumers should
+// deduplicate on the event ID, which is the ID of the source row.
+package eventstream
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+)
+
+// Store is the persistence used by the service (implemented by postgres.TenantStore)
+type Store interface {
+	SetOutboxTriggers(ctx context.Context, types []domain.StreamEventType) error
+	PublishOutboxEvents(ctx context.Context, limit int, publish func([]domain.OutboxEvent) error) (int, error)
+	DeletePublishedOutboxEvents(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+const (
+	// publishTimeout bounds how long a batch waits for the broker
+	publishTimeout = 30 * time.Second
+	// cleanupInterval is how often published events are deleted
+	cleanupInterval = time.Hour
+)
+
+// Service publishes the event outbox
+type Service struct {
+	cfg       config.EventStreamConfig
+	store     Store
+	publisher Publisher
+	encode    func(*Envelope) ([]byte, error)
+	now       func() time.Time
+}
+
+// NewService creates an event stream service. When the stream is disabled the
+// service only removes the outbox triggers.
+func NewService(cfg config.EventStreamConfig, store Store) (*Service, error) {
+	s := &Service{cfg: cfg, store: store, encode: encodeJSON, now: time.Now}
+	if cfg.Format == "protobuf" {
+		s.encode = encodeProtobuf
+	}
+	if !cfg.Enabled {
+		return s, nil
+	}
+	switch cfg.Broker {
+	case "kafka":
+		s.publisher = newKafkaPublisher(cfg.Brokers)
+	case "nats":
+		p, err := newNATSPublisher(cfg.NATSURL)
+		if err != nil {
+			return nil, err
+		}
+		s.publisher = p
+	default:
+		return nil, fmt.Errorf("unknown event stream broker %q", cfg.Broker)
+	}
+	return s, nil
+}
+
+// Topic returns the Kafka topic or NATS subject of an event type
+func (s *Service) Topic(t domain.StreamEventType) string {
+	if topic := s.cfg.Topics[string(t)]; topic != "" {
+		return topic
+	}
+	return "modelgate." + string(t)
+}
+
+// Start installs the outbox triggers of the configured event types, then
+// publishes the outbox until ctx is done
+func (s *Service) Start(ctx context.Context) {
+	var types []domain.StreamEventType
+	if s.cfg.Enabled {
+		for _, e := range s.cfg.Events {
+			types = append(types, domain.StreamEventType(e))
+		}
+	}
+	if err := s.store.SetOutboxTriggers(ctx, types); err != nil {
+		slog.Error("Failed to set event outbox triggers", "error", err)
+	}
+	if !s.cfg.Enabled {
+		return
+	}
+	slog.Info("Event streaming enabled", "broker", s.cfg.Broker, "format", s.cfg.Format, "events", s.cfg.Events)
+
+	go func() {
+		defer s.publisher.Close()
+		poll := time.NewTicker(s.cfg.PollInterval)
+		defer poll.Stop()
+		cleanup := time.NewTicker(cleanupInterval)
+		defer cleanup.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-poll.C:
+				s.drain(ctx)
+			case <-cleanup.C:
+				s.cleanup(ctx)
+			}
+		}
+	}()
+}
+
+// drain publishes batches until the events due are all handled or a batch
+// fails; failed events are retried with backoff by later polls
+func (s *Service) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		var publishErr error
+		n, err := s.store.PublishOutboxEvents(ctx, s.cfg.BatchSize, func(events []domain.OutboxEvent) error {
+			publishErr = s.publish(ctx, events)
+			return publishErr
+		})
+		if err == nil {
+			err = publishErr
+		}
+		if err != nil {
+			slog.Warn("Failed to publish event outbox", "error", err)
+			return
+		}
+		if n < s.cfg.BatchSize {
+			return
+		}
+	}
+}
+
+// publish encodes and sends a batch. An event that can't be encoded is
+// logged and skipped, so it doesn't hold up the outbox.
+func (s *Service) publish(ctx context.Context, events []domain.OutboxEvent) error {
+	msgs := make([]Message, 0, len(events))
+	for _, event := range events {
+		msg, err := s.message(event)
+		if err != nil {
+			slog.Error("Skipping event that can't be encoded", "outbox_id", event.ID, "type", event.Type, "error", err)
+			continue
+		}
+		msgs = append(msgs, msg)
+	}
+	if len(msgs) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, publishTimeout)
+	defer cancel()
+	if err := s.publisher.Publish(ctx, msgs); err != nil {
+		return fmt.Errorf("publish to %s: %w", s.cfg.Broker, err)
+	}
+	return nil
+}
+
+func (s *Service) message(event domain.OutboxEvent) (Message, error) {
+	env, err := newEnvelope(event)
+	if err != nil {
+		return Message{}, err
+	}
+	value, err := s.encode(env)
+	if err != nil {
+		return Message{}, err
+	}
+	contentType := "application/json"
+	if s.cfg.Format == "protobuf" {
+		contentType = "application/x-protobuf"
+	}
+	return Message{
+		Topic: s.Topic(event.Type),
+		Key:   env.ID,
+		Value: value,
+		Headers: map[string]string{
+			"content-type":   contentType,
+			"event-type":     string(event.Type),
+			"schema-version": strconv.Itoa(SchemaVersion),
+		},
+	}, nil
+}
+
+// cleanup deletes events published longer ago than the retention
+func (s *Service) cleanup(ctx context.Context) {
+	if s.cfg.Retention <= 0 {
+		return
+	}
+	deleted, err := s.store.DeletePublishedOutboxEvents(ctx, s.now().Add(-s.cfg.Retention))
+	if err != nil {
+		slog.Warn("Failed to delete published outbox events", "error", err)
+		return
+	}
+	if deleted > 0 {
+		slog.Debug("Deleted published outbox events", "count", deleted)
+	}
+}
@@ -0,0 +1,151 @@
+package eventstream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// memOutbox keeps events pending until a publish succeeds
+type memOutbox struct {
+	pending   []domain.OutboxEvent
+	published []domain.OutboxEvent
+}
+
+func (m *memOutbox) SetOutboxTriggers(ctx context.Context, types []domain.StreamEventType) error {
+	return nil
+}
+
+func (m *memOutbox) PublishOutboxEvents(ctx context.Context, limit int, publish func([]domain.OutboxEvent) error) (int, error) {
+	batch := m.pending[:min(limit, len(m.pending))]
+	if len(batch) == 0 {
+		return 0, nil
+	}
+	if err := publish(batch); err != nil {
+		return len(batch), nil // Retried later
+	}
+	m.published = append(m.published, batch...)
+	m.pending = m.pending[len(batch):]
+	return len(batch), nil
+}
+
+func (m *memOutbox) DeletePublishedOutboxEvents(ctx context.Context, cutoff time.Time) (int64, error) {
+	return 0, nil
+}
+
+type fakePublisher struct {
+	fail bool
+	sent []Message
+}
+
+func (p *fakePublisher) Publish(ctx context.Context, msgs []Message) error {
+	if p.fail {
+		return errors.New("broker unavailable")
+	}
+	p.sent = append(p.sent, msgs...)
+	return nil
+}
+
+func (p *fakePublisher) Close() error { return nil }
+
+func TestPublishOutbox(t *testing.T) {
+	created := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	store := &memOutbox{pending: []domain.OutboxEvent{
+		{ID: 1, Type: domain.StreamEventUsage, CreatedAt: created, Payload: json.RawMessage(
+			`{"id":"u1","model":"gpt-4o","input_tokens":10,"cost_usd":0.0012,"is_success":true,"metadata":{"tag":"x"}}`)},
+		{ID: 2, Type: domain.StreamEventPolicyViolation, CreatedAt: created, Payload: json.RawMessage(
+			`{"id":"v1","violation_type":"prompt_injection","severity":3}`)},
+		{ID: 3, Type: "unknown", CreatedAt: created, Payload: json.RawMessage(`{}`)},
+		{ID: 4, Type: domain.StreamEventAudit, CreatedAt: created, Payload: json.RawMessage(
+			`{"id":"a1","action":"create","resource_type":"api_key"}`)},
+	}}
+	svc, err := NewService(config.EventStreamConfig{Format: "json", BatchSize: 2,
+		Topics: map[string]string{"audit": "audit-log"}}, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := &fakePublisher{fail: true}
+	svc.publisher = pub
+
+	// A broker outage leaves the events in the outbox
+	svc.drain(context.Background())
+	if len(store.pending) != 4 {
+		t.Fatalf("expected events to stay pending, %d left", len(store.pending))
+	}
+
+	pub.fail = false
+	svc.drain(context.Background())
+	if len(store.pending) != 0 {
+		t.Fatalf("expected the outbox to drain, %d left", len(store.pending))
+	}
+	// The event of unknown type is skipped rather than holding up the outbox
+	if len(pub.sent) != 3 {
+		t.Fatalf("expected 3 messages, got %d", len(pub.sent))
+	}
+	if pub.sent[0].Topic != "modelgate.usage" || pub.sent[2].Topic != "audit-log" || pub.sent[0].Key != "u1" {
+		t.Fatalf("unexpected routing %+v", pub.sent)
+	}
+
+	var env struct {
+		ID            string         `json:"id"`
+		Type          string         `json:"type"`
+		SchemaVersion int            `json:"schema_version"`
+		Data          map[string]any `json:"data"`
+	}
+	if err := json.Unmarshal(pub.sent[0].Value, &env); err != nil {
+		t.Fatal(err)
+	}
+	if env.ID != "u1" || env.Type != "usage" || env.SchemaVersion != 1 || env.Data["model"] != "gpt-4o" {
+		t.Fatalf("unexpected envelope %+v", env)
+	}
+	if _, ok := env.Data["metadata"]; ok {
+		t.Fatal("expected columns outside the schema to be left out")
+	}
+}
+
+func TestEncodeProtobuf(t *testing.T) {
+	env, err := newEnvelope(domain.OutboxEvent{ID: 7, Type: domain.StreamEventPolicyViolation, CreatedAt: time.Now(),
+		Payload: json.RawMessage(`{"id":"v1","violation_type":"pii","severity":2}`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := encodeProtobuf(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fields := map[protowire.Number][]byte{}
+	var version uint64
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		b = b[n:]
+		if typ == protowire.VarintType {
+			version, n = protowire.ConsumeVarint(b)
+		} else {
+			fields[num], n = protowire.ConsumeBytes(b)
+		}
+		if n < 0 {
+			t.Fatal(protowire.ParseError(n))
+		}
+		b = b[n:]
+	}
+	if string(fields[1]) != "v1" || string(fields[2]) != "policy_violation" || version != SchemaVersion {
+		t.Fatalf("unexpected envelope fields %q %q %d", fields[1], fields[2], version)
+	}
+	var data structpb.Struct
+	if err := proto.Unmarshal(fields[5], &data); err != nil {
+		t.Fatal(err)
+	}
+	if data.Fields["violation_type"].GetStringValue() != "pii" || data.Fields["severity"].GetNumberValue() != 2 {
+		t.Fatalf("unexpected data %v", data.Fields)
+	}
+}
@@ -0,0 +1,417 @@
+// Package experiments runs A/B (or N-way) experiments on chat traffic. A
+// running experiment assigns each request of its audience a variant by
+// weight; the variant can change the model and the system prompt. Requests
+// of a conversation stay on one variant. Usage records keep the experiment
+// and variant, which the results compare. An experiment starts applying
+// within refreshInterval on every instance.
+package experiments
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+// Store is the persistence used by the service (implemented by postgres.TenantStore)
+type Store interface {
+	ListExperiments(ctx context.Context) ([]*domain.Experiment, error)
+	GetExperiment(ctx context.Context, id string) (*domain.Experiment, error)
+	CreateExperiment(ctx context.Context, e *domain.Experiment) error
+	UpdateExperiment(ctx context.Context, e *domain.Experiment) error
+	DeleteExperiment(ctx context.Context, id string) (bool, error)
+	GetExperimentVariantStats(ctx context.Context, experimentID string, startTime, endTime time.Time) ([]domain.ExperimentVariantStats, error)
+	CreateAuditLog(ctx context.Context, log *domain.AuditLog) error
+}
+
+// refreshInterval bounds how long a change made on another instance takes to apply here
+const refreshInterval = 10 * time.Second
+
+// Actor is who changed an experiment, for the audit log
+type Actor struct {
+	ID        string
+	Email     string
+	IPAddress string
+	UserAgent string
+}
+
+// Service assigns requests to experiment variants from a cached copy of the
+// running experiments
+type Service struct {
+	store Store
+
+	mu      sync.RWMutex
+	running []*domain.Experiment // Oldest started first
+}
+
+// NewService creates a new experiment service
+func NewService(store Store) *Service {
+	return &Service{store: store}
+}
+
+// Assign enrolls a request in the first running experiment it matches, the
+// one started first, and applies the variant it draws. It returns the
+// assignment, also set on the request, or nil.
+func (s *Service) Assign(req *domain.ChatRequest) *domain.ExperimentAssignment {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, e := range s.running {
+		if !matches(e, req) {
+			continue
+		}
+		key := req.ConversationID
+		if key == "" {
+			key = req.RequestID
+		}
+		v := pick(e, key)
+		apply(v, req)
+		req.Experiment = &domain.ExperimentAssignment{ExperimentID: e.ID, Variant: v.Name, Model: v.Model}
+		return req.Experiment
+	}
+	return nil
+}
+
+// matches reports whether a request is for the experiment's model and of its audience
+func matches(e *domain.Experiment, req *domain.ChatRequest) bool {
+	if e.Model != "" && req.Model != e.Model && !strings.HasSuffix(req.Model, "/"+e.Model) {
+		return false
+	}
+	if len(e.RoleIDs) == 0 && len(e.APIKeyIDs) == 0 {
+		return true
+	}
+	return (req.RoleID != "" && slices.Contains(e.RoleIDs, req.RoleID)) ||
+		(req.APIKeyID != "" && slices.Contains(e.APIKeyIDs, req.APIKeyID))
+}
+
+// pick draws a variant by weight. The same key always draws the same variant
+// of an experiment.
+func pick(e *domain.Experiment, key string) *domain.ExperimentVariant {
+	total := 0
+	for _, v := range e.Variants {
+		total += v.Weight
+	}
+	h := fnv.New64a()
+	h.Write([]byte(e.ID))
+	h.Write([]byte{0})
+	h.Write([]byte(key))
+	n := int(h.Sum64() % uint64(total))
+	for i := range e.Variants {
+		if n -= e.Variants[i].Weight; n < 0 {
+			return &e.Variants[i]
+		}
+	}
+	return &e.Variants[len(e.Variants)-1]
+}
+
+// apply changes a request as a variant says
+func apply(v *domain.ExperimentVariant, req *domain.ChatRequest) {
+	if v.Model != "" {
+		req.Model = v.Model
+	}
+	if v.SystemPrompt == "" {
+		return
+	}
+	switch v.SystemPromptMode {
+	case domain.SystemPromptPrepend:
+		req.SystemPrompt = joinPrompts(v.SystemPrompt, req.SystemPrompt)
+	case domain.SystemPromptAppend:
+		req.SystemPrompt = joinPrompts(req.SystemPrompt, v.SystemPrompt)
+	default:
+		req.SystemPrompt = v.SystemPrompt
+		req.Messages = slices.DeleteFunc(slices.Clone(req.Messages), func(m domain.Message) bool { return m.Role == "system" })
+	}
+}
+
+func joinPrompts(first, second string) string {
+	if first == "" || second == "" {
+		return first + second
+	}
+	return first + "\n\n" + second
+}
+
+// List returns every experiment, newest first
+func (s *Service) List(ctx context.Context) ([]*domain.Experiment, error) {
+	return s.store.ListExperiments(ctx)
+}
+
+// Get returns an experiment, or nil if it does not exist
+func (s *Service) Get(ctx context.Context, id string) (*domain.Experiment, error) {
+	return s.store.GetExperiment(ctx, id)
+}
+
+// Create adds an experiment as a draft
+func (s *Service) Create(ctx context.Context, e domain.Experiment, actor Actor) (*domain.Experiment, error) {
+	e.Status = domain.ExperimentDraft
+	e.CreatedBy = actor.Email
+	e.StartedAt, e.EndedAt = nil, nil
+	if err := e.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.store.CreateExperiment(ctx, &e); err != nil {
+		return nil, fmt.Errorf("create experiment: %w", err)
+	}
+	s.audit(ctx, domain.AuditActionCreate, &e, actor, map[string]any{"variants": variantNames(&e)})
+	return &e, nil
+}
+
+// Update changes an experiment's definition. A running experiment must be
+// paused first, and a completed one can't be changed, so that results
+// describe what the variants were.
+func (s *Service) Update(ctx context.Context, e domain.Experiment, actor Actor) (*domain.Experiment, error) {
+	existing, err := s.store.GetExperiment(ctx, e.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get experiment: %w", err)
+	}
+	if existing == nil {
+		return nil, fmt.Errorf("experiment not found: %s", e.ID)
+	}
+	switch existing.Status {
+	case domain.ExperimentRunning:
+		return nil, fmt.Errorf("pause experiment %q before changing it", existing.Name)
+	case domain.ExperimentCompleted:
+		return nil, fmt.Errorf("experiment %q is completed and can't be changed", existing.Name)
+	}
+	e.Status, e.CreatedBy, e.CreatedAt = existing.Status, existing.CreatedBy, existing.CreatedAt
+	e.StartedAt, e.EndedAt = existing.StartedAt, existing.EndedAt
+	if err := e.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.store.UpdateExperiment(ctx, &e); err != nil {
+		return nil, fmt.Errorf("update experiment: %w", err)
+	}
+	s.audit(ctx, domain.AuditActionUpdate, &e, actor, map[string]any{"variants": variantNames(&e)})
+	return &e, nil
+}
+
+// SetStatus starts, pauses or completes an experiment. A completed experiment
+// can't be restarted.
+func (s *Service) SetStatus(ctx context.Context, id string, status domain.ExperimentStatus, actor Actor) (*domain.Experiment, error) {
+	e, err := s.store.GetExperiment(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("get experiment: %w", err)
+	}
+	if e == nil {
+		return nil, fmt.Errorf("experiment not found: %s", id)
+	}
+	if e.Status == status {
+		return e, nil
+	}
+	if e.Status == domain.ExperimentCompleted {
+		return nil, fmt.Errorf("experiment %q is completed", e.Name)
+	}
+
+	now := time.Now()
+	switch status {
+	case domain.ExperimentRunning:
+		if e.StartedAt == nil {
+			e.StartedAt = &now
+		}
+	case domain.ExperimentPaused:
+		if e.Status != domain.ExperimentRunning {
+			return nil, fmt.Errorf("experiment %q is not running", e.Name)
+		}
+	case domain.ExperimentCompleted:
+		e.EndedAt = &now
+	default:
+		return nil, fmt.Errorf("can't set experiment status to %q", status)
+	}
+	previous := e.Status
+	e.Status = status
+	if err := s.store.UpdateExperiment(ctx, e); err != nil {
+		return nil, fmt.Errorf("update experiment: %w", err)
+	}
+	s.cache(e)
+
+	s.audit(ctx, domain.AuditActionUpdate, e, actor, map[string]any{"status": string(status), "previous_status": string(previous)})
+	slog.Info("Experiment status changed", "experiment", e.Name, "status", status, "by", actor.Email)
+	return e, nil
+}
+
+// Delete removes an experiment and reports whether there was one. Usage
+// records keep its ID.
+func (s *Service) Delete(ctx context.Context, id string, actor Actor) (bool, error) {
+	e, err := s.store.GetExperiment(ctx, id)
+	if err != nil {
+		return false, fmt.Errorf("get experiment: %w", err)
+	}
+	if e == nil {
+		return false, nil
+	}
+	deleted, err := s.store.DeleteExperiment(ctx, id)
+	if err != nil {
+		return false, fmt.Errorf("delete experiment: %w", err)
+	}
+	e.Status = domain.ExperimentCompleted
+	s.cache(e)
+	if deleted {
+		s.audit(ctx, domain.AuditActionDelete, e, actor, nil)
+	}
+	return deleted, nil
+}
+
+// Results compares an experiment's variants between from and to. A zero from
+// is when the experiment started, and a zero to is when it ended, or now.
+// Variants that no longer exist but have usage come last.
+func (s *Service) Results(ctx context.Context, id string, from, to time.Time) (*domain.ExperimentResults, error) {
+	e, err := s.store.GetExperiment(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("get experiment: %w", err)
+	}
+	if e == nil {
+		return nil, fmt.Errorf("experiment not found: %s", id)
+	}
+	if from.IsZero() {
+		from = e.CreatedAt
+		if e.StartedAt != nil {
+			from = *e.StartedAt
+		}
+	}
+	if to.IsZero() {
+		to = time.Now()
+		if e.EndedAt != nil {
+			to = *e.EndedAt
+		}
+	}
+	if !to.After(from) {
+		return nil, fmt.Errorf("to must be after from")
+	}
+
+	stats, err := s.store.GetExperimentVariantStats(ctx, id, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("get variant stats: %w", err)
+	}
+	byVariant := make(map[string]domain.ExperimentVariantStats, len(stats))
+	var total int64
+	for _, st := range stats {
+		byVariant[st.Variant] = st
+		total += st.Requests
+	}
+
+	results := &domain.ExperimentResults{Experiment: e, From: from, To: to}
+	for _, v := range e.Variants {
+		st, ok := byVariant[v.Name]
+		if !ok {
+			st = domain.ExperimentVariantStats{Variant: v.Name}
+		}
+		delete(byVariant, v.Name)
+		st.Weight = v.Weight
+		results.Variants = append(results.Variants, st)
+	}
+	removed := make([]domain.ExperimentVariantStats, 0, len(byVariant))
+	for _, st := range byVariant {
+		removed = append(removed, st)
+	}
+	sort.Slice(removed, func(i, j int) bool { return removed[i].Variant < removed[j].Variant })
+	results.Variants = append(results.Variants, removed...)
+
+	for i := range results.Variants {
+		if total > 0 {
+			results.Variants[i].Share = float64(results.Variants[i].Requests) / float64(total)
+		}
+	}
+	return results, nil
+}
+
+// Refresh reloads the running experiments from the store
+func (s *Service) Refresh(ctx context.Context) error {
+	experiments, err := s.store.ListExperiments(ctx)
+	if err != nil {
+		return fmt.Errorf("list experiments: %w", err)
+	}
+	var running []*domain.Experiment
+	for _, e := range experiments {
+		if e.Status == domain.ExperimentRunning && e.Validate() == nil {
+			running = append(running, e)
+		}
+	}
+	sortRunning(running)
+
+	s.mu.Lock()
+	s.running = running
+	s.mu.Unlock()
+	return nil
+}
+
+// Start loads the running experiments and keeps them fresh until ctx is done
+func (s *Service) Start(ctx context.Context) {
+	if err := s.Refresh(ctx); err != nil {
+		slog.Warn("Failed to load experiments", "error", err)
+	}
+	go func() {
+		ticker := time.NewTicker(refreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.Refresh(ctx); err != nil {
+					slog.Warn("Failed to refresh experiments", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// cache applies a status change on this instance at once
+func (s *Service) cache(e *domain.Experiment) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	running := slices.DeleteFunc(slices.Clone(s.running), func(r *domain.Experiment) bool { return r.ID == e.ID })
+	if e.Status == domain.ExperimentRunning {
+		copied := *e
+		running = append(running, &copied)
+		sortRunning(running)
+	}
+	s.running = running
+}
+
+func sortRunning(running []*domain.Experiment) {
+	sort.SliceStable(running, func(i, j int) bool {
+		return startedAt(running[i]).Before(startedAt(running[j]))
+	})
+}
+
+func startedAt(e *domain.Experiment) time.Time {
+	if e.StartedAt != nil {
+		return *e.StartedAt
+	}
+	return e.CreatedAt
+}
+
+func variantNames(e *domain.Experiment) []string {
+	names := make([]string, len(e.Variants))
+	for i, v := range e.Variants {
+		names[i] = v.Name
+	}
+	return names
+}
+
+func (s *Service) audit(ctx context.Context, action domain.AuditAction, e *domain.Experiment, actor Actor, details map[string]any) {
+	entry := &domain.AuditLog{
+		Action:       action,
+		ResourceType: domain.AuditResourceExperiment,
+		ResourceID:   e.ID,
+		ResourceName: e.Name,
+		ActorID:      actor.ID,
+		ActorEmail:   actor.Email,
+		ActorType:    "admin",
+		IPAddress:    actor.IPAddress,
+		UserAgent:    actor.UserAgent,
+		Details:      details,
+		Status:       "success",
+	}
+	if err := s.store.CreateAuditLog(context.WithoutCancel(ctx), entry); err != nil {
+		slog.Error("Failed to audit experiment change", "experiment", e.Name, "error", err)
+	}
+}
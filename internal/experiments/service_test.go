@@ -0,0 +1,261 @@
+package experiments
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+type memStore struct {
+	experiments map[string]*domain.Experiment
+	stats       []domain.ExperimentVariantStats
+	audits      []*domain.AuditLog
+	nextID      int
+}
+
+func (m *memStore) ListExperiments(ctx context.Context) ([]*domain.Experiment, error) {
+	var list []*domain.Experiment
+	for _, e := range m.experiments {
+		copied := *e
+		list = append(list, &copied)
+	}
+	return list, nil
+}
+
+func (m *memStore) GetExperiment(ctx context.Context, id string) (*domain.Experiment, error) {
+	e, ok := m.experiments[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *e
+	return &copied, nil
+}
+
+func (m *memStore) CreateExperiment(ctx context.Context, e *domain.Experiment) error {
+	m.nextID++
+	e.ID = fmt.Sprintf("exp-%d", m.nextID)
+	e.CreatedAt, e.UpdatedAt = time.Now(), time.Now()
+	copied := *e
+	m.experiments[e.ID] = &copied
+	return nil
+}
+
+func (m *memStore) UpdateExperiment(ctx context.Context, e *domain.Experiment) error {
+	e.UpdatedAt = time.Now()
+	copied := *e
+	m.experiments[e.ID] = &copied
+	return nil
+}
+
+func (m *memStore) DeleteExperiment(ctx context.Context, id string) (bool, error) {
+	_, ok := m.experiments[id]
+	delete(m.experiments, id)
+	return ok, nil
+}
+
+func (m *memStore) GetExperimentVariantStats(ctx context.Context, experimentID string, startTime, endTime time.Time) ([]domain.ExperimentVariantStats, error) {
+	return m.stats, nil
+}
+
+func (m *memStore) CreateAuditLog(ctx context.Context, log *domain.AuditLog) error {
+	m.audits = append(m.audits, log)
+	return nil
+}
+
+func newTestService() (*Service, *memStore) {
+	store := &memStore{experiments: make(map[string]*domain.Experiment)}
+	return NewService(store), store
+}
+
+var admin = Actor{ID: "u1", Email: "admin@example.com"}
+
+func TestAssign(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newTestService()
+
+	e, err := svc.Create(ctx, domain.Experiment{
+		Name:    "mini vs full",
+		Model:   "gpt-4o",
+		RoleIDs: []string{"role-a"},
+		Variants: []domain.ExperimentVariant{
+			{Name: "control", Weight: 3},
+			{Name: "mini", Weight: 1, Model: "openai/gpt-4o-mini", SystemPrompt: "Be brief.", SystemPromptMode: domain.SystemPromptPrepend},
+		},
+	}, admin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Status != domain.ExperimentDraft {
+		t.Fatalf("expected a new experiment to be a draft, got %s", e.Status)
+	}
+
+	newReq := func(id string) *domain.ChatRequest {
+		return &domain.ChatRequest{RequestID: id, Model: "openai/gpt-4o", RoleID: "role-a", SystemPrompt: "You help."}
+	}
+	if a := svc.Assign(newReq("r0")); a != nil {
+		t.Fatalf("expected a draft not to assign requests, got %+v", a)
+	}
+
+	if _, err := svc.SetStatus(ctx, e.ID, domain.ExperimentRunning, admin); err != nil {
+		t.Fatal(err)
+	}
+
+	// Traffic splits by weight, and each variant changes the request as it says
+	counts := map[string]int{}
+	for i := 0; i < 4000; i++ {
+		req := newReq(fmt.Sprintf("r%d", i))
+		a := svc.Assign(req)
+		if a == nil || req.Experiment != a || a.ExperimentID != e.ID {
+			t.Fatalf("expected request %d to be assigned, got %+v", i, a)
+		}
+		counts[a.Variant]++
+		switch a.Variant {
+		case "control":
+			if req.Model != "openai/gpt-4o" || req.SystemPrompt != "You help." || a.PinsModel() {
+				t.Fatalf("expected the control to leave the request alone, got %s %q", req.Model, req.SystemPrompt)
+			}
+		case "mini":
+			if req.Model != "openai/gpt-4o-mini" || req.SystemPrompt != "Be brief.\n\nYou help." || !a.PinsModel() {
+				t.Fatalf("expected the mini variant to apply, got %s %q", req.Model, req.SystemPrompt)
+			}
+		}
+	}
+	if share := float64(counts["mini"]) / 4000; share < 0.2 || share > 0.3 {
+		t.Fatalf("expected about a quarter of requests on mini, got %.3f", share)
+	}
+
+	// Requests of a conversation stay on one variant
+	first := newReq("c1")
+	first.ConversationID = "conv-1"
+	variant := svc.Assign(first).Variant
+	for i := 0; i < 20; i++ {
+		req := newReq(fmt.Sprintf("c%d", i+2))
+		req.ConversationID = "conv-1"
+		if got := svc.Assign(req).Variant; got != variant {
+			t.Fatalf("expected the conversation to stay on %s, got %s", variant, got)
+		}
+	}
+
+	// Other roles and other models don't take part
+	other := newReq("x1")
+	other.RoleID = "role-b"
+	if svc.Assign(other) != nil {
+		t.Fatal("expected a request of another role not to take part")
+	}
+	other = newReq("x2")
+	other.Model = "anthropic/claude-sonnet-4"
+	if svc.Assign(other) != nil {
+		t.Fatal("expected a request for another model not to take part")
+	}
+
+	// Paused experiments stop assigning at once
+	if _, err := svc.SetStatus(ctx, e.ID, domain.ExperimentPaused, admin); err != nil {
+		t.Fatal(err)
+	}
+	if svc.Assign(newReq("p1")) != nil {
+		t.Fatal("expected a paused experiment not to assign requests")
+	}
+
+	var nilSvc *Service
+	if nilSvc.Assign(newReq("n1")) != nil {
+		t.Fatal("expected a nil service not to assign requests")
+	}
+}
+
+func TestReplaceSystemPrompt(t *testing.T) {
+	req := &domain.ChatRequest{
+		SystemPrompt: "Old.",
+		Messages: []domain.Message{
+			{Role: "system", Content: []domain.ContentBlock{{Type: "text", Text: "Also old."}}},
+			{Role: "user", Content: []domain.ContentBlock{{Type: "text", Text: "Hi"}}},
+		},
+	}
+	apply(&domain.ExperimentVariant{SystemPrompt: "New."}, req)
+	if req.SystemPrompt != "New." || len(req.Messages) != 1 || req.Messages[0].Role != "user" {
+		t.Fatalf("expected the system prompt to be replaced, got %q with %d messages", req.SystemPrompt, len(req.Messages))
+	}
+}
+
+func TestLifecycle(t *testing.T) {
+	ctx := context.Background()
+	svc, store := newTestService()
+
+	if _, err := svc.Create(ctx, domain.Experiment{Name: "one", Variants: []domain.ExperimentVariant{{Name: "a", Weight: 1}}}, admin); err == nil {
+		t.Fatal("expected an experiment with one variant to be rejected")
+	}
+	if _, err := svc.Create(ctx, domain.Experiment{Name: "dup", Variants: []domain.ExperimentVariant{{Name: "a", Weight: 1}, {Name: "a", Weight: 1}}}, admin); err == nil {
+		t.Fatal("expected duplicate variants to be rejected")
+	}
+
+	e, err := svc.Create(ctx, domain.Experiment{Name: "prompts", Variants: []domain.ExperimentVariant{
+		{Name: "a", Weight: 1},
+		{Name: "b", Weight: 1, SystemPrompt: "Answer in French."},
+	}}, admin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.SetStatus(ctx, e.ID, domain.ExperimentPaused, admin); err == nil {
+		t.Fatal("expected pausing a draft to fail")
+	}
+	running, err := svc.SetStatus(ctx, e.ID, domain.ExperimentRunning, admin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if running.StartedAt == nil {
+		t.Fatal("expected starting to set startedAt")
+	}
+
+	// A running experiment must be paused to change
+	changed := *running
+	changed.Variants = []domain.ExperimentVariant{{Name: "a", Weight: 1}, {Name: "c", Weight: 2}}
+	if _, err := svc.Update(ctx, changed, admin); err == nil {
+		t.Fatal("expected changing a running experiment to fail")
+	}
+	if _, err := svc.SetStatus(ctx, e.ID, domain.ExperimentPaused, admin); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Update(ctx, changed, admin); err != nil {
+		t.Fatal(err)
+	}
+
+	// Results follow the current variants; a removed one with usage comes last
+	store.stats = []domain.ExperimentVariantStats{
+		{Variant: "b", Requests: 30},
+		{Variant: "a", Requests: 10},
+	}
+	results, err := svc.Results(ctx, e.ID, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, v := range results.Variants {
+		got = append(got, fmt.Sprintf("%s:%d:%d:%.2f", v.Variant, v.Weight, v.Requests, v.Share))
+	}
+	if want := "[a:1:10:0.25 c:2:0:0.00 b:0:30:0.75]"; fmt.Sprint(got) != want {
+		t.Fatalf("expected results %s, got %v", want, got)
+	}
+	if !results.From.Equal(*running.StartedAt) {
+		t.Fatalf("expected results to start when the experiment did, got %s", results.From)
+	}
+
+	// Completed is final
+	if _, err := svc.SetStatus(ctx, e.ID, domain.ExperimentCompleted, admin); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.SetStatus(ctx, e.ID, domain.ExperimentRunning, admin); err == nil {
+		t.Fatal("expected restarting a completed experiment to fail")
+	}
+	if store.experiments[e.ID].EndedAt == nil {
+		t.Fatal("expected completing to set endedAt")
+	}
+
+	if ok, err := svc.Delete(ctx, e.ID, admin); err != nil || !ok {
+		t.Fatalf("expected the experiment to be deleted, got %v %v", ok, err)
+	}
+	if len(store.audits) != 6 {
+		t.Fatalf("expected every change to be audited, got %d entries", len(store.audits))
+	}
+}
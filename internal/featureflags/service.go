@@ -0,0 +1,187 @@
+// Package featureflags gates new gateway behaviors per tenant and per role and
+// records every flag change in a changelog.
+package featureflags
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+// Store is the persistence used by the service (implemented by postgres.TenantStore)
+type Store interface {
+	ListFeatureFlags(ctx context.Context) (map[string]*domain.FeatureFlag, error)
+	SaveFeatureFlag(ctx context.Context, flag *domain.FeatureFlag, change *domain.FeatureFlagChange) error
+	ListFeatureFlagChanges(ctx context.Context, filter domain.FeatureFlagChangeFilter) ([]*domain.FeatureFlagChange, error)
+}
+
+// refreshInterval bounds how long a change made on another instance takes to apply here
+const refreshInterval = 30 * time.Second
+
+// Service evaluates flags from a cached copy of the stored state
+type Service struct {
+	store Store
+
+	mu       sync.RWMutex
+	stored   map[string]*domain.FeatureFlag
+	loadedAt time.Time
+}
+
+// NewService creates a new feature flag service
+func NewService(store Store) *Service {
+	return &Service{store: store}
+}
+
+// Update changes a flag. With an empty RoleID it sets the tenant-wide value
+// (Enabled is required); otherwise it sets the role's override, and a nil
+// Enabled clears the override.
+type Update struct {
+	Key        string
+	RoleID     string
+	Enabled    *bool
+	Note       string
+	ActorID    string
+	ActorEmail string
+}
+
+// IsEnabled reports whether a flag is on for a caller with the given roles.
+// A nil service, an unknown flag or a store failure fall back to the flag's default.
+func (s *Service) IsEnabled(ctx context.Context, key string, roleIDs ...string) bool {
+	def, ok := domain.FeatureFlagDefinitionFor(key)
+	if !ok {
+		return false
+	}
+	if s == nil {
+		return def.DefaultEnabled
+	}
+	return s.resolve(def, s.cached(ctx)[key]).EnabledFor(roleIDs...)
+}
+
+// List returns every known flag with its current state
+func (s *Service) List(ctx context.Context) ([]domain.FeatureFlag, error) {
+	stored, err := s.store.ListFeatureFlags(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list feature flags: %w", err)
+	}
+	s.setCache(stored)
+
+	flags := make([]domain.FeatureFlag, 0, len(domain.FeatureFlags))
+	for _, def := range domain.FeatureFlags {
+		flags = append(flags, *s.resolve(def, stored[def.Key]))
+	}
+	return flags, nil
+}
+
+// Set applies an update and records it in the changelog. It returns the new
+// state of the flag and the changelog entry.
+func (s *Service) Set(ctx context.Context, u Update) (*domain.FeatureFlag, *domain.FeatureFlagChange, error) {
+	def, ok := domain.FeatureFlagDefinitionFor(u.Key)
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown feature flag %q", u.Key)
+	}
+	if u.RoleID == "" && u.Enabled == nil {
+		return nil, nil, fmt.Errorf("enabled is required for the tenant-wide value")
+	}
+
+	stored, err := s.store.ListFeatureFlags(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("list feature flags: %w", err)
+	}
+	flag := s.resolve(def, stored[u.Key])
+
+	change := &domain.FeatureFlagChange{
+		FlagKey:    u.Key,
+		RoleID:     u.RoleID,
+		Enabled:    u.Enabled,
+		Note:       u.Note,
+		ActorID:    u.ActorID,
+		ActorEmail: u.ActorEmail,
+	}
+	if u.RoleID == "" {
+		previous := flag.Enabled
+		change.PreviousEnabled = &previous
+		flag.Enabled = *u.Enabled
+	} else {
+		if previous, ok := flag.RoleOverrides[u.RoleID]; ok {
+			change.PreviousEnabled = &previous
+		}
+		if u.Enabled == nil {
+			delete(flag.RoleOverrides, u.RoleID)
+		} else {
+			flag.RoleOverrides[u.RoleID] = *u.Enabled
+		}
+	}
+	flag.UpdatedBy = u.ActorEmail
+
+	if err := s.store.SaveFeatureFlag(ctx, flag, change); err != nil {
+		return nil, nil, fmt.Errorf("save feature flag: %w", err)
+	}
+	now := time.Now()
+	flag.UpdatedAt = &now
+
+	// Reload on the next check so the change applies here right away
+	s.mu.Lock()
+	s.loadedAt = time.Time{}
+	s.mu.Unlock()
+
+	slog.Info("Feature flag changed", "flag", u.Key, "role_id", u.RoleID, "enabled", u.Enabled, "actor", u.ActorEmail)
+	return flag, change, nil
+}
+
+// Changelog returns recorded flag changes, newest first
+func (s *Service) Changelog(ctx context.Context, filter domain.FeatureFlagChangeFilter) ([]*domain.FeatureFlagChange, error) {
+	return s.store.ListFeatureFlagChanges(ctx, filter)
+}
+
+// resolve combines a flag's definition with its stored state (nil if never set)
+func (s *Service) resolve(def domain.FeatureFlagDefinition, stored *domain.FeatureFlag) *domain.FeatureFlag {
+	flag := &domain.FeatureFlag{
+		Key:            def.Key,
+		Description:    def.Description,
+		Enabled:        def.DefaultEnabled,
+		DefaultEnabled: def.DefaultEnabled,
+		RoleOverrides:  make(map[string]bool),
+	}
+	if stored != nil {
+		flag.Enabled = stored.Enabled
+		for id, enabled := range stored.RoleOverrides {
+			flag.RoleOverrides[id] = enabled
+		}
+		flag.UpdatedBy = stored.UpdatedBy
+		flag.UpdatedAt = stored.UpdatedAt
+	}
+	return flag
+}
+
+// cached returns the stored flags, reloading them when the cache is stale.
+// On a load failure the previous copy (or none, meaning defaults) is used.
+func (s *Service) cached(ctx context.Context) map[string]*domain.FeatureFlag {
+	s.mu.RLock()
+	stored, fresh := s.stored, time.Since(s.loadedAt) < refreshInterval
+	s.mu.RUnlock()
+	if fresh {
+		return stored
+	}
+
+	loaded, err := s.store.ListFeatureFlags(ctx)
+	if err != nil {
+		slog.Warn("Failed to load feature flags, using last known state", "error", err)
+		s.mu.Lock()
+		s.loadedAt = time.Now() // Don't retry on every request
+		s.mu.Unlock()
+		return stored
+	}
+	s.setCache(loaded)
+	return loaded
+}
+
+func (s *Service) setCache(stored map[string]*domain.FeatureFlag) {
+	s.mu.Lock()
+	s.stored = stored
+	s.loadedAt = time.Now()
+	s.mu.Unlock()
+}
@@ -0,0 +1,94 @@
+package featureflags
+
+import (
+	"context"
+	"testing"
+
+	"modelgate/internal/domain"
+)
+
+// memStore is an in-memory Store for tests
+type memStore struct {
+	flags   map[string]*domain.FeatureFlag
+	changes []*domain.FeatureFlagChange
+}
+
+func (m *memStore) ListFeatureFlags(context.Context) (map[string]*domain.FeatureFlag, error) {
+	out := make(map[string]*domain.FeatureFlag, len(m.flags))
+	for k, f := range m.flags {
+		copied := *f
+		out[k] = &copied
+	}
+	return out, nil
+}
+
+func (m *memStore) SaveFeatureFlag(_ context.Context, flag *domain.FeatureFlag, change *domain.FeatureFlagChange) error {
+	copied := *flag
+	m.flags[flag.Key] = &copied
+	m.changes = append([]*domain.FeatureFlagChange{change}, m.changes...)
+	return nil
+}
+
+func (m *memStore) ListFeatureFlagChanges(_ context.Context, filter domain.FeatureFlagChangeFilter) ([]*domain.FeatureFlagChange, error) {
+	var out []*domain.FeatureFlagChange
+	for _, c := range m.changes {
+		if filter.FlagKey == "" || c.FlagKey == filter.FlagKey {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func TestFlagsFollowRoleOverrides(t *testing.T) {
+	ctx := context.Background()
+	store := &memStore{flags: make(map[string]*domain.FeatureFlag)}
+	svc := NewService(store)
+	key := domain.FeatureToolCatalog
+	on, off := true, false
+
+	if !svc.IsEnabled(ctx, key, "role-a") {
+		t.Fatal("expected flag to start at its default (enabled)")
+	}
+	if svc.IsEnabled(ctx, "no_such_flag") {
+		t.Fatal("expected unknown flags to be disabled")
+	}
+
+	// Turn the flag off tenant-wide, then back on for one role
+	if _, _, err := svc.Set(ctx, Update{Key: key, Enabled: &off, Note: "incident"}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if _, _, err := svc.Set(ctx, Update{Key: key, RoleID: "role-a", Enabled: &on}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if !svc.IsEnabled(ctx, key, "role-a") || svc.IsEnabled(ctx, key, "role-b") || svc.IsEnabled(ctx, key) {
+		t.Error("expected only role-a to have the flag enabled")
+	}
+
+	// Clearing the override falls back to the tenant-wide value
+	_, change, err := svc.Set(ctx, Update{Key: key, RoleID: "role-a"})
+	if err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if change.Enabled != nil || change.PreviousEnabled == nil || !*change.PreviousEnabled {
+		t.Errorf("clearing change = %+v, want enabled nil and previous true", change)
+	}
+	if svc.IsEnabled(ctx, key, "role-a") {
+		t.Error("expected role-a to follow the tenant-wide value after clearing its override")
+	}
+
+	changes, _ := svc.Changelog(ctx, domain.FeatureFlagChangeFilter{FlagKey: key})
+	if len(changes) != 3 || changes[2].Note != "incident" || changes[2].PreviousEnabled == nil || !*changes[2].PreviousEnabled {
+		t.Errorf("changelog = %+v, want 3 entries starting with the tenant-wide change", changes)
+	}
+
+	if _, _, err := svc.Set(ctx, Update{Key: key}); err == nil {
+		t.Error("expected an error when the tenant-wide value is missing")
+	}
+}
+
+func TestNilServiceUsesDefaults(t *testing.T) {
+	var svc *Service
+	if !svc.IsEnabled(context.Background(), domain.FeatureRateLimitHeaders) {
+		t.Error("expected a nil service to return the flag default")
+	}
+}
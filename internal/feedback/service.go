@@ -0,0 +1,209 @@
+// Package feedback collects what clients and reviewers think of responses and
+// turns the approved ones into fine-tuning datasets.
+package feedback
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+var (
+	// ErrNotFound is returned when there is no response with the request ID
+	ErrNotFound = errors.New("response not found")
+
+	// ErrInvalidFeedback is returned when feedback fails validation
+	ErrInvalidFeedback = errors.New("invalid feedback")
+)
+
+// DefaultMinRating is the lowest rating an exported response may have
+const DefaultMinRating = 4
+
+// exportPageSize is how many feedback rows an export reads at most
+const exportPageSize = 10000
+
+// Store is the persistence used by the service (implemented by postgres.TenantStore)
+type Store interface {
+	SaveFeedback(ctx context.Context, f *domain.Feedback, apiKeyID string) (bool, error)
+	ListFeedback(ctx context.Context, filter domain.FeedbackFilter) ([]*domain.Feedback, error)
+	GetFeedbackStats(ctx context.Context, startTime, endTime time.Time, byRole bool, projectID string) ([]*domain.FeedbackStats, error)
+	GetUsageRecord(ctx context.Context, id string) (*domain.UsageRecord, error)
+	OpenCapturedContent(ctx context.Context, record *domain.UsageRecord) error
+}
+
+// Service stores feedback and exports it
+type Service struct {
+	store Store
+}
+
+// NewService creates a new feedback service
+func NewService(store Store) *Service {
+	return &Service{store: store}
+}
+
+// RequestIDFromResponseID returns the request ID behind a chat completion ID
+// ("chatcmpl-<request ID>"); other IDs are taken to be request IDs
+func RequestIDFromResponseID(id string) string {
+	return strings.TrimPrefix(strings.TrimSpace(id), "chatcmpl-")
+}
+
+// Submit stores feedback on the response to f.RequestID, replacing earlier
+// feedback on it. With apiKeyID, only that key's responses can be rated.
+func (s *Service) Submit(ctx context.Context, f *domain.Feedback, apiKeyID string) error {
+	f.RequestID = RequestIDFromResponseID(f.RequestID)
+	if f.RequestID == "" {
+		return fmt.Errorf("%w: response_id or request_id is required", ErrInvalidFeedback)
+	}
+	f.Comment = strings.TrimSpace(f.Comment)
+	if err := f.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFeedback, err)
+	}
+	found, err := s.store.SaveFeedback(ctx, f, apiKeyID)
+	if err != nil {
+		return fmt.Errorf("save feedback: %w", err)
+	}
+	if !found {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// List returns feedback matching a filter, newest first
+func (s *Service) List(ctx context.Context, filter domain.FeedbackFilter) ([]*domain.Feedback, error) {
+	return s.store.ListFeedback(ctx, filter)
+}
+
+// Stats sums up feedback per model, or per role with byRole
+func (s *Service) Stats(ctx context.Context, from, to time.Time, byRole bool, projectID string) ([]*domain.FeedbackStats, error) {
+	return s.store.GetFeedbackStats(ctx, from, to, byRole, projectID)
+}
+
+// ExportOptions selects the responses to export. Zero values don't filter.
+type ExportOptions struct {
+	From      time.Time
+	To        time.Time
+	Model     string
+	MinRating int // Without thumbs, the lowest rating exported; DefaultMinRating when 0
+}
+
+// ExportReport says what an export wrote and why it left responses out
+type ExportReport struct {
+	Examples    int  `json:"examples"`
+	NotPositive int  `json:"not_positive"`
+	NotCaptured int  `json:"not_captured"` // Content was not captured with the request
+	NotTextual  int  `json:"not_textual"`  // Images, files or tool calls
+	Unreadable  int  `json:"unreadable"`   // Usage record gone or content undecryptable
+	Truncated   bool `json:"truncated"`    // More feedback than one export reads
+}
+
+// example is one line of an OpenAI chat fine-tuning file
+type example struct {
+	Messages []exampleMessage `json:"messages"`
+}
+
+type exampleMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Export writes the positively rated responses, with the requests that led to
+// them, as chat fine-tuning JSONL. Only requests whose content was captured
+// ([replay] capture_content) and that are plain text can be exported.
+func (s *Service) Export(ctx context.Context, w io.Writer, opts ExportOptions) (*ExportReport, error) {
+	if opts.MinRating == 0 {
+		opts.MinRating = DefaultMinRating
+	}
+	list, err := s.store.ListFeedback(ctx, domain.FeedbackFilter{
+		From:  opts.From,
+		To:    opts.To,
+		Model: opts.Model,
+		Limit: exportPageSize,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list feedback: %w", err)
+	}
+
+	report := &ExportReport{Truncated: len(list) == exportPageSize}
+	enc := json.NewEncoder(w)
+	for _, f := range list {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		if !f.Positive(opts.MinRating) {
+			report.NotPositive++
+			continue
+		}
+		record, err := s.store.GetUsageRecord(ctx, f.UsageRecordID)
+		if err != nil || record == nil {
+			report.Unreadable++
+			continue
+		}
+		if err := s.store.OpenCapturedContent(ctx, record); err != nil {
+			report.Unreadable++
+			continue
+		}
+		ex, ok, err := buildExample(record)
+		if err != nil {
+			report.Unreadable++
+			continue
+		}
+		if ex == nil {
+			report.NotCaptured++
+			continue
+		}
+		if !ok {
+			report.NotTextual++
+			continue
+		}
+		if err := enc.Encode(ex); err != nil {
+			return report, err
+		}
+		report.Examples++
+	}
+	return report, nil
+}
+
+// buildExample turns a usage record's captured request and response into a
+// fine-tuning example. It returns nil when nothing was captured and false
+// when the conversation is not plain text.
+func buildExample(record *domain.UsageRecord) (*example, bool, error) {
+	captured, ok := record.Metadata["request"]
+	response, _ := record.Metadata["response"].(string)
+	if !ok || captured == nil || response == "" {
+		return nil, false, nil
+	}
+	body, err := json.Marshal(captured)
+	if err != nil {
+		return nil, false, err
+	}
+	var req domain.ChatRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, false, err
+	}
+
+	ex := &example{}
+	if req.SystemPrompt != "" {
+		ex.Messages = append(ex.Messages, exampleMessage{Role: "system", Content: req.SystemPrompt})
+	}
+	for _, m := range req.Messages {
+		if len(m.ToolCalls) > 0 || m.ToolCallID != "" {
+			return ex, false, nil
+		}
+		var text strings.Builder
+		for _, block := range m.Content {
+			if block.Type != "text" {
+				return ex, false, nil
+			}
+			text.WriteString(block.Text)
+		}
+		ex.Messages = append(ex.Messages, exampleMessage{Role: m.Role, Content: text.String()})
+	}
+	ex.Messages = append(ex.Messages, exampleMessage{Role: "assistant", Content: response})
+	return ex, true, nil
+}
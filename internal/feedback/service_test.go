@@ -0,0 +1,124 @@
+package feedback
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+type memStore struct {
+	records  map[string]*domain.UsageRecord
+	feedback []*domain.Feedback
+	apiKeys  map[string]string // request ID -> API key
+}
+
+func (m *memStore) SaveFeedback(ctx context.Context, f *domain.Feedback, apiKeyID string) (bool, error) {
+	key, ok := m.apiKeys[f.RequestID]
+	if !ok || (apiKeyID != "" && key != apiKeyID) {
+		return false, nil
+	}
+	f.UsageRecordID = "rec-" + f.RequestID
+	m.feedback = append(m.feedback, f)
+	return true, nil
+}
+
+func (m *memStore) ListFeedback(ctx context.Context, filter domain.FeedbackFilter) ([]*domain.Feedback, error) {
+	return m.feedback, nil
+}
+
+func (m *memStore) GetFeedbackStats(ctx context.Context, startTime, endTime time.Time, byRole bool, projectID string) ([]*domain.FeedbackStats, error) {
+	return nil, nil
+}
+
+func (m *memStore) GetUsageRecord(ctx context.Context, id string) (*domain.UsageRecord, error) {
+	return m.records[id], nil
+}
+
+func (m *memStore) OpenCapturedContent(ctx context.Context, record *domain.UsageRecord) error {
+	return nil
+}
+
+func ptr[T any](v T) *T { return &v }
+
+// captured round-trips a request the way recordUsage stores it in metadata
+func captured(t *testing.T, req *domain.ChatRequest) any {
+	body, _ := json.Marshal(req)
+	var out any
+	if err := json.Unmarshal(body, &out); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestSubmit(t *testing.T) {
+	store := &memStore{apiKeys: map[string]string{"req-1": "key-1"}}
+	svc := NewService(store)
+	ctx := context.Background()
+
+	if err := svc.Submit(ctx, &domain.Feedback{RequestID: "chatcmpl-req-1", Rating: ptr(7)}, "key-1"); !errors.Is(err, ErrInvalidFeedback) {
+		t.Fatal("expected an out of range rating to be rejected")
+	}
+	if err := svc.Submit(ctx, &domain.Feedback{RequestID: "req-1"}, "key-1"); !errors.Is(err, ErrInvalidFeedback) {
+		t.Fatal("expected empty feedback to be rejected")
+	}
+	if err := svc.Submit(ctx, &domain.Feedback{RequestID: "req-1", ThumbsUp: ptr(true)}, "key-2"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected another key's response to be not found, got %v", err)
+	}
+
+	f := &domain.Feedback{RequestID: "chatcmpl-req-1", ThumbsUp: ptr(true), Comment: "  great  "}
+	if err := svc.Submit(ctx, f, "key-1"); err != nil {
+		t.Fatal(err)
+	}
+	if f.RequestID != "req-1" || f.Comment != "great" || f.UsageRecordID != "rec-req-1" {
+		t.Errorf("unexpected feedback %+v", f)
+	}
+}
+
+func TestExport(t *testing.T) {
+	text := func(role, s string) domain.Message {
+		return domain.Message{Role: role, Content: []domain.ContentBlock{{Type: "text", Text: s}}}
+	}
+	plain := &domain.ChatRequest{
+		SystemPrompt: "Be brief.",
+		Messages:     []domain.Message{text("user", "Capital of France?")},
+	}
+	image := &domain.ChatRequest{
+		Messages: []domain.Message{{Role: "user", Content: []domain.ContentBlock{{Type: "image", ImageURL: "https://example.com/a.png"}}}},
+	}
+	store := &memStore{
+		records: map[string]*domain.UsageRecord{
+			"rec-1": {ID: "rec-1", Metadata: map[string]any{"request": captured(t, plain), "response": "Paris."}},
+			"rec-2": {ID: "rec-2", Metadata: map[string]any{"request": captured(t, image), "response": "A cat."}},
+			"rec-3": {ID: "rec-3", Metadata: map[string]any{}},
+		},
+		feedback: []*domain.Feedback{
+			{UsageRecordID: "rec-1", Rating: ptr(5)},
+			{UsageRecordID: "rec-2", ThumbsUp: ptr(true)},
+			{UsageRecordID: "rec-3", ThumbsUp: ptr(true)},
+			{UsageRecordID: "rec-1", ThumbsUp: ptr(false), Rating: ptr(5)},
+			{UsageRecordID: "rec-1", Rating: ptr(3)},
+			{UsageRecordID: "gone", ThumbsUp: ptr(true)},
+		},
+	}
+
+	var out bytes.Buffer
+	report, err := NewService(store).Export(context.Background(), &out, ExportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ExportReport{Examples: 1, NotPositive: 2, NotCaptured: 1, NotTextual: 1, Unreadable: 1}
+	if *report != want {
+		t.Errorf("report = %+v, want %+v", *report, want)
+	}
+	line := strings.TrimSpace(out.String())
+	wantLine := `{"messages":[{"role":"system","content":"Be brief."},{"role":"user","content":"Capital of France?"},{"role":"assistant","content":"Paris."}]}`
+	if line != wantLine {
+		t.Errorf("export = %s\nwant %s", line, wantLine)
+	}
+}
@@ -0,0 +1,815 @@
+// Package files implements the files API: resumable chunked uploads, file
+// storage on local disk or in an object store, and passthrough to providers'
+// native files APIs.
+package files
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+	"modelgate/internal/objectstore"
+)
+
+// Errors returned by the service. HTTP handlers map these to status codes.
+var (
+	ErrUploadNotFound   = errors.New("upload not found")
+	ErrFileNotFound     = errors.New("file not found")
+	ErrUploadNotPending = errors.New("upload is not pending")
+	ErrInvalidUpload    = errors.New("invalid upload")
+	ErrPartTooLarge     = errors.New("part exceeds maximum part size")
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+	ErrFileTooLarge     = errors.New("file exceeds maximum size")
+	ErrFileType         = errors.New("file type not allowed")
+	ErrNoPassthrough    = errors.New("provider does not support file passthrough")
+	ErrFileUnusable     = errors.New("file cannot be used with this request")
+)
+
+// Store is the persistence used by the service (implemented by postgres.TenantStore)
+type Store interface {
+	CreateUpload(ctx context.Context, upload *domain.Upload) error
+	GetUpload(ctx context.Context, id string) (*domain.Upload, error)
+	UpdateUploadStatus(ctx context.Context, id string, status domain.UploadStatus) (bool, error)
+	ListExpiredUploads(ctx context.Context, now time.Time, limit int) ([]*domain.Upload, error)
+	SaveUploadPart(ctx context.Context, part *domain.UploadPart) error
+	ListUploadParts(ctx context.Context, uploadID string) ([]*domain.UploadPart, error)
+	CompleteUpload(ctx context.Context, uploadID string, file *domain.File) error
+	CreateFile(ctx context.Context, file *domain.File) error
+	GetFile(ctx context.Context, id string) (*domain.File, error)
+	ListFiles(ctx context.Context, apiKeyID, purpose string, limit int) ([]*domain.File, error)
+	ListExpiredFiles(ctx context.Context, now time.Time, limit int) ([]*domain.File, error)
+	MarkFileDeleted(ctx context.Context, id string) (bool, error)
+}
+
+// ClientResolver returns the provider client that serves a model (implemented by gateway.Service)
+type ClientResolver interface {
+	GetClientForModel(ctx context.Context, model string) (domain.LLMClient, error)
+}
+
+// CreateUploadRequest describes a new upload session
+type CreateUploadRequest struct {
+	Filename string
+	Purpose  string
+	MimeType string
+	Bytes    int64
+	SHA256   string // Optional expected checksum of the whole file
+	APIKeyID string
+}
+
+// UploadFileRequest describes a file uploaded in a single request
+type UploadFileRequest struct {
+	Filename string
+	Purpose  string
+	MimeType string // Detected from the file name when empty
+	APIKeyID string
+	Model    string             // Pass the file through to the files API of the provider serving this model
+	Policy   *domain.FilePolicy // File policy of the uploader's role, if any
+}
+
+// Service manages upload sessions and files. Parts are written to local disk
+// and stitched into a single file when the upload is completed; completed
+// files are kept on disk or in the configured object store.
+type Service struct {
+	config  config.FilesConfig
+	store   Store
+	objects objectstore.Store // nil keeps files on local disk
+	clients ClientResolver    // nil disables provider passthrough
+}
+
+// NewService creates a new files service
+func NewService(cfg config.FilesConfig, store Store) *Service {
+	return &Service{config: cfg, store: store}
+}
+
+// SetObjectStore keeps completed files in an object store instead of on local disk
+func (s *Service) SetObjectStore(objects objectstore.Store) {
+	s.objects = objects
+}
+
+// SetClients enables passing files through to providers' native files APIs
+func (s *Service) SetClients(clients ClientResolver) {
+	s.clients = clients
+}
+
+// CreateUpload opens a new upload session
+func (s *Service) CreateUpload(ctx context.Context, req CreateUploadRequest) (*domain.Upload, error) {
+	if req.Filename == "" || req.Purpose == "" {
+		return nil, fmt.Errorf("%w: filename and purpose are required", ErrInvalidUpload)
+	}
+	if req.Bytes <= 0 {
+		return nil, fmt.Errorf("%w: bytes must be positive", ErrInvalidUpload)
+	}
+	if s.config.MaxFileSize > 0 && req.Bytes > s.config.MaxFileSize {
+		return nil, fmt.Errorf("%w: file exceeds maximum size of %d bytes", ErrInvalidUpload, s.config.MaxFileSize)
+	}
+
+	upload := &domain.Upload{
+		APIKeyID:  req.APIKeyID,
+		Filename:  filepath.Base(req.Filename),
+		Purpose:   req.Purpose,
+		MimeType:  req.MimeType,
+		Bytes:     req.Bytes,
+		SHA256:    strings.ToLower(req.SHA256),
+		Status:    domain.UploadStatusPending,
+		ExpiresAt: time.Now().Add(s.config.UploadTTL),
+	}
+	if err := s.store.CreateUpload(ctx, upload); err != nil {
+		return nil, fmt.Errorf("create upload: %w", err)
+	}
+	return upload, nil
+}
+
+// GetUpload returns an upload and the parts received so far, so a client
+// can work out which parts still need to be sent after an interruption.
+func (s *Service) GetUpload(ctx context.Context, id string) (*domain.Upload, []*domain.UploadPart, error) {
+	upload, err := s.getUpload(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	parts, err := s.store.ListUploadParts(ctx, id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("list parts: %w", err)
+	}
+	return upload, parts, nil
+}
+
+// PutPart stores a part. If expectedSHA256 is set, the part is rejected when
+// its checksum does not match. Re-sending a part number replaces it.
+func (s *Service) PutPart(ctx context.Context, uploadID string, partNumber int, body io.Reader, expectedSHA256 string) (*domain.UploadPart, error) {
+	upload, err := s.pendingUpload(ctx, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if partNumber < 1 {
+		return nil, fmt.Errorf("%w: part number must be at least 1", ErrInvalidUpload)
+	}
+
+	dir := s.uploadDir(upload.ID)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("create upload dir: %w", err)
+	}
+
+	// Write to a temp file first so a dropped connection never leaves a partial part behind
+	tmp, err := os.CreateTemp(dir, "incoming-*")
+	if err != nil {
+		return nil, fmt.Errorf("create temp part: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	limit := upload.Bytes
+	if s.config.MaxPartSize > 0 && s.config.MaxPartSize < limit {
+		limit = s.config.MaxPartSize
+	}
+	hasher := sha256.New()
+	n, err := io.Copy(io.MultiWriter(tmp, hasher), io.LimitReader(body, limit+1))
+	closeErr := tmp.Close()
+	if err != nil {
+		return nil, fmt.Errorf("read part: %w", err)
+	}
+	if closeErr != nil {
+		return nil, fmt.Errorf("write part: %w", closeErr)
+	}
+	if n > limit {
+		return nil, ErrPartTooLarge
+	}
+	if n == 0 {
+		return nil, fmt.Errorf("%w: part is empty", ErrInvalidUpload)
+	}
+
+	sum := hex.EncodeToString(hasher.Sum(nil))
+	if expectedSHA256 != "" && !strings.EqualFold(expectedSHA256, sum) {
+		return nil, fmt.Errorf("%w: part %d", ErrChecksumMismatch, partNumber)
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("part-%d", partNumber))
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return nil, fmt.Errorf("store part: %w", err)
+	}
+
+	part := &domain.UploadPart{
+		UploadID:    upload.ID,
+		PartNumber:  partNumber,
+		Bytes:       n,
+		SHA256:      sum,
+		StoragePath: path,
+	}
+	if err := s.store.SaveUploadPart(ctx, part); err != nil {
+		return nil, fmt.Errorf("save part: %w", err)
+	}
+	return part, nil
+}
+
+// Complete stitches the given parts (in the given order) into a single file.
+// The combined size must match the declared size, and the whole-file checksum
+// must match if one was supplied when the upload was created.
+func (s *Service) Complete(ctx context.Context, uploadID string, partNumbers []int) (*domain.File, error) {
+	upload, err := s.pendingUpload(ctx, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if len(partNumbers) == 0 {
+		return nil, fmt.Errorf("%w: part list is empty", ErrInvalidUpload)
+	}
+
+	stored, err := s.store.ListUploadParts(ctx, uploadID)
+	if err != nil {
+		return nil, fmt.Errorf("list parts: %w", err)
+	}
+	byNumber := make(map[int]*domain.UploadPart, len(stored))
+	for _, p := range stored {
+		byNumber[p.PartNumber] = p
+	}
+
+	ordered := make([]*domain.UploadPart, 0, len(partNumbers))
+	seen := make(map[int]bool, len(partNumbers))
+	var total int64
+	for _, num := range partNumbers {
+		p, ok := byNumber[num]
+		if !ok {
+			return nil, fmt.Errorf("%w: part %d was not uploaded", ErrInvalidUpload, num)
+		}
+		if seen[num] {
+			return nil, fmt.Errorf("%w: part %d listed twice", ErrInvalidUpload, num)
+		}
+		seen[num] = true
+		total += p.Bytes
+		ordered = append(ordered, p)
+	}
+	if total != upload.Bytes {
+		return nil, fmt.Errorf("%w: parts total %d bytes, expected %d", ErrInvalidUpload, total, upload.Bytes)
+	}
+
+	filesDir := filepath.Join(s.config.StorageDir, "files")
+	if err := os.MkdirAll(filesDir, 0o750); err != nil {
+		return nil, fmt.Errorf("create files dir: %w", err)
+	}
+	out, err := os.CreateTemp(filesDir, "stitch-*")
+	if err != nil {
+		return nil, fmt.Errorf("create file: %w", err)
+	}
+	defer os.Remove(out.Name())
+
+	hasher := sha256.New()
+	if err := stitchParts(io.MultiWriter(out, hasher), ordered); err != nil {
+		out.Close()
+		return nil, err
+	}
+	if err := out.Close(); err != nil {
+		return nil, fmt.Errorf("write file: %w", err)
+	}
+
+	sum := hex.EncodeToString(hasher.Sum(nil))
+	if upload.SHA256 != "" && upload.SHA256 != sum {
+		return nil, fmt.Errorf("%w: file", ErrChecksumMismatch)
+	}
+
+	file := &domain.File{
+		APIKeyID: upload.APIKeyID,
+		Filename: upload.Filename,
+		Purpose:  upload.Purpose,
+		MimeType: upload.MimeType,
+		Bytes:    total,
+		SHA256:   sum,
+	}
+	// The final name is the checksum so the path is known before the DB row exists
+	if err := s.keep(ctx, file, out.Name(), upload.ID+"-"+sum[:16]); err != nil {
+		return nil, err
+	}
+	if err := s.store.CompleteUpload(ctx, uploadID, file); err != nil {
+		s.removeContent(ctx, file)
+		return nil, fmt.Errorf("complete upload: %w", err)
+	}
+
+	// Parts are no longer needed once the file is stitched
+	if err := os.RemoveAll(s.uploadDir(uploadID)); err != nil {
+		slog.Warn("Failed to remove upload parts", "upload_id", uploadID, "error", err)
+	}
+	return file, nil
+}
+
+// Cancel cancels a pending upload and removes its parts
+func (s *Service) Cancel(ctx context.Context, uploadID string) (*domain.Upload, error) {
+	upload, err := s.getUpload(ctx, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := s.store.UpdateUploadStatus(ctx, uploadID, domain.UploadStatusCancelled)
+	if err != nil {
+		return nil, fmt.Errorf("cancel upload: %w", err)
+	}
+	if !ok {
+		return nil, ErrUploadNotPending
+	}
+	upload.Status = domain.UploadStatusCancelled
+	// The janitor no longer sees cancelled uploads, so leftover parts are only logged
+	if err := os.RemoveAll(s.uploadDir(uploadID)); err != nil {
+		slog.Warn("Failed to remove cancelled upload parts", "upload_id", uploadID, "error", err)
+	}
+	return upload, nil
+}
+
+// GetFile returns a completed file, or nil if it doesn't exist or was deleted
+func (s *Service) GetFile(ctx context.Context, id string) (*domain.File, error) {
+	file, err := s.store.GetFile(ctx, id)
+	if err != nil || file == nil || file.Status == domain.FileStatusDeleted {
+		return nil, err
+	}
+	return file, nil
+}
+
+// ListFiles lists files, newest first. An empty apiKeyID or purpose matches all.
+func (s *Service) ListFiles(ctx context.Context, apiKeyID, purpose string, limit int) ([]*domain.File, error) {
+	return s.store.ListFiles(ctx, apiKeyID, purpose, limit)
+}
+
+// OpenFile returns a completed file and a reader for its content. The caller must close the reader.
+func (s *Service) OpenFile(ctx context.Context, id string) (*domain.File, io.ReadCloser, error) {
+	file, err := s.GetFile(ctx, id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get file: %w", err)
+	}
+	if file == nil {
+		return nil, nil, ErrFileNotFound
+	}
+	content, err := s.openContent(ctx, file)
+	if err != nil {
+		return nil, nil, err
+	}
+	return file, content, nil
+}
+
+// UploadFile stores a file sent in one request. The role's file policy can
+// lower the maximum size, restrict the content type and shorten retention.
+// With a model, the content is uploaded to that model's provider instead of
+// being stored.
+func (s *Service) UploadFile(ctx context.Context, req UploadFileRequest, content io.Reader) (*domain.File, error) {
+	if req.Filename == "" || req.Purpose == "" {
+		return nil, fmt.Errorf("%w: file and purpose are required", ErrInvalidUpload)
+	}
+	mimeType := req.MimeType
+	if mimeType == "" || mimeType == "application/octet-stream" {
+		if detected := mime.TypeByExtension(filepath.Ext(req.Filename)); detected != "" {
+			mimeType = detected
+		}
+	}
+	policy := req.Policy
+	if policy == nil {
+		policy = &domain.FilePolicy{}
+	}
+	if !policy.AllowsMimeType(mimeType) {
+		return nil, fmt.Errorf("%w: %s", ErrFileType, mimeType)
+	}
+	maxSize := s.config.MaxFileSize
+	if policy.Enabled && policy.MaxFileSize > 0 && (maxSize <= 0 || policy.MaxFileSize < maxSize) {
+		maxSize = policy.MaxFileSize
+	}
+
+	filesDir := filepath.Join(s.config.StorageDir, "files")
+	if err := os.MkdirAll(filesDir, 0o750); err != nil {
+		return nil, fmt.Errorf("create files dir: %w", err)
+	}
+	out, err := os.CreateTemp(filesDir, "upload-*")
+	if err != nil {
+		return nil, fmt.Errorf("create file: %w", err)
+	}
+	defer os.Remove(out.Name())
+
+	reader := content
+	if maxSize > 0 {
+		reader = io.LimitReader(content, maxSize+1)
+	}
+	hasher := sha256.New()
+	n, err := io.Copy(io.MultiWriter(out, hasher), reader)
+	closeErr := out.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("write file: %w", err)
+	}
+	if maxSize > 0 && n > maxSize {
+		return nil, fmt.Errorf("%w of %d bytes", ErrFileTooLarge, maxSize)
+	}
+	if n == 0 {
+		return nil, fmt.Errorf("%w: file is empty", ErrInvalidUpload)
+	}
+
+	file := &domain.File{
+		APIKeyID:  req.APIKeyID,
+		Filename:  filepath.Base(req.Filename),
+		Purpose:   req.Purpose,
+		MimeType:  mimeType,
+		Bytes:     n,
+		SHA256:    hex.EncodeToString(hasher.Sum(nil)),
+		ExpiresAt: s.expiry(policy),
+	}
+	if req.Model != "" {
+		err = s.passThrough(ctx, file, req.Model, out.Name())
+	} else {
+		err = s.keep(ctx, file, out.Name(), "file-"+strings.TrimPrefix(filepath.Base(out.Name()), "upload-"))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.CreateFile(ctx, file); err != nil {
+		s.removeContent(ctx, file)
+		return nil, fmt.Errorf("record file: %w", err)
+	}
+	return file, nil
+}
+
+// DeleteFile deletes a file's content and marks it deleted
+func (s *Service) DeleteFile(ctx context.Context, id string) error {
+	file, err := s.GetFile(ctx, id)
+	if err != nil {
+		return fmt.Errorf("get file: %w", err)
+	}
+	if file == nil {
+		return ErrFileNotFound
+	}
+	return s.deleteFile(ctx, file)
+}
+
+func (s *Service) deleteFile(ctx context.Context, file *domain.File) error {
+	ok, err := s.store.MarkFileDeleted(ctx, file.ID)
+	if err != nil {
+		return fmt.Errorf("delete file: %w", err)
+	}
+	if !ok {
+		return ErrFileNotFound
+	}
+	s.removeContent(ctx, file)
+	return nil
+}
+
+// CreateFile stores content produced by the gateway itself (e.g. batch results) as a file.
+// Filename, Purpose, MimeType, APIKeyID and ExpiresAt are taken from meta.
+func (s *Service) CreateFile(ctx context.Context, meta domain.File, content io.Reader) (*domain.File, error) {
+	filesDir := filepath.Join(s.config.StorageDir, "files")
+	if err := os.MkdirAll(filesDir, 0o750); err != nil {
+		return nil, fmt.Errorf("create files dir: %w", err)
+	}
+	out, err := os.CreateTemp(filesDir, "file-*")
+	if err != nil {
+		return nil, fmt.Errorf("create file: %w", err)
+	}
+
+	hasher := sha256.New()
+	n, err := io.Copy(io.MultiWriter(out, hasher), content)
+	closeErr := out.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(out.Name())
+		return nil, fmt.Errorf("write file: %w", err)
+	}
+
+	file := &domain.File{
+		APIKeyID:  meta.APIKeyID,
+		Filename:  filepath.Base(meta.Filename),
+		Purpose:   meta.Purpose,
+		MimeType:  meta.MimeType,
+		Bytes:     n,
+		SHA256:    hex.EncodeToString(hasher.Sum(nil)),
+		ExpiresAt: meta.ExpiresAt,
+	}
+	if err := s.keep(ctx, file, out.Name(), filepath.Base(out.Name())); err != nil {
+		os.Remove(out.Name())
+		return nil, err
+	}
+	if err := s.store.CreateFile(ctx, file); err != nil {
+		s.removeContent(ctx, file)
+		return nil, fmt.Errorf("record file: %w", err)
+	}
+	return file, nil
+}
+
+// ResolveContent fills in the file blocks of a request's messages. Files
+// passed through to a provider are referenced by the provider's ID and can
+// only be used with models of that provider; other files are inlined.
+// apiKeyID, when set, restricts the request to the key's own files.
+func (s *Service) ResolveContent(ctx context.Context, req *domain.ChatRequest, apiKeyID string) error {
+	var provider domain.Provider
+	for i := range req.Messages {
+		for j := range req.Messages[i].Content {
+			block := &req.Messages[i].Content[j]
+			if block.Type != "file" || block.FileID == "" || len(block.FileData) > 0 {
+				continue
+			}
+			file, err := s.GetFile(ctx, block.FileID)
+			if err != nil {
+				return fmt.Errorf("get file: %w", err)
+			}
+			if file == nil || (apiKeyID != "" && file.APIKeyID != "" && file.APIKeyID != apiKeyID) {
+				return fmt.Errorf("%w: %s", ErrFileNotFound, block.FileID)
+			}
+			if file.ExpiresAt != nil && time.Now().After(*file.ExpiresAt) {
+				return fmt.Errorf("%w: %s", ErrFileNotFound, block.FileID)
+			}
+			if block.Filename == "" {
+				block.Filename = file.Filename
+			}
+			block.MediaType = file.MimeType
+
+			if file.Storage == domain.FileStorageProvider {
+				if provider == "" {
+					if provider, err = s.modelProvider(ctx, req.Model); err != nil {
+						return err
+					}
+				}
+				if provider != file.Provider {
+					return fmt.Errorf("%w: file %s is stored with %s and cannot be sent to %s", ErrFileUnusable, file.ID, file.Provider, provider)
+				}
+				block.ProviderFileID = file.ProviderFileID
+				continue
+			}
+
+			if max := s.config.MaxInlineSize; max > 0 && file.Bytes > max {
+				return fmt.Errorf("%w: file %s is larger than the %d bytes that can be sent inline", ErrFileUnusable, file.ID, max)
+			}
+			content, err := s.openContent(ctx, file)
+			if err != nil {
+				return err
+			}
+			block.FileData, err = io.ReadAll(content)
+			content.Close()
+			if err != nil {
+				return fmt.Errorf("read file: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// CleanupExpiredFiles deletes files whose retention has run out. Returns the
+// number of files deleted.
+func (s *Service) CleanupExpiredFiles(ctx context.Context) (int, error) {
+	expired, err := s.store.ListExpiredFiles(ctx, time.Now(), 100)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, file := range expired {
+		if err := s.deleteFile(ctx, file); err != nil {
+			if errors.Is(err, ErrFileNotFound) {
+				continue
+			}
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// CleanupExpired marks abandoned uploads as expired and removes their parts.
+// Returns the number of uploads cleaned up.
+func (s *Service) CleanupExpired(ctx context.Context) (int, error) {
+	uploads, err := s.store.ListExpiredUploads(ctx, time.Now(), 100)
+	if err != nil {
+		return 0, err
+	}
+
+	cleaned := 0
+	for _, upload := range uploads {
+		ok, err := s.store.UpdateUploadStatus(ctx, upload.ID, domain.UploadStatusExpired)
+		if err != nil {
+			return cleaned, err
+		}
+		if !ok {
+			continue
+		}
+		if err := os.RemoveAll(s.uploadDir(upload.ID)); err != nil {
+			slog.Warn("Failed to remove expired upload parts", "upload_id", upload.ID, "error", err)
+		}
+		cleaned++
+	}
+	return cleaned, nil
+}
+
+// StartJanitor periodically cleans up expired uploads and files until ctx is cancelled
+func (s *Service) StartJanitor(ctx context.Context) {
+	interval := s.config.CleanupInterval
+	if interval <= 0 {
+		interval = 10 * time.Minute
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				n, err := s.CleanupExpired(ctx)
+				if err != nil {
+					slog.Error("Failed to clean up expired uploads", "error", err)
+				} else if n > 0 {
+					slog.Info("Cleaned up expired uploads", "count", n)
+				}
+				n, err = s.CleanupExpiredFiles(ctx)
+				if err != nil {
+					slog.Error("Failed to delete expired files", "error", err)
+				} else if n > 0 {
+					slog.Info("Deleted expired files", "count", n)
+				}
+			}
+		}
+	}()
+}
+
+func (s *Service) getUpload(ctx context.Context, id string) (*domain.Upload, error) {
+	upload, err := s.store.GetUpload(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("get upload: %w", err)
+	}
+	if upload == nil {
+		return nil, ErrUploadNotFound
+	}
+	return upload, nil
+}
+
+// pendingUpload loads an upload and checks that it still accepts parts
+func (s *Service) pendingUpload(ctx context.Context, id string) (*domain.Upload, error) {
+	upload, err := s.getUpload(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if upload.Status != domain.UploadStatusPending {
+		return nil, ErrUploadNotPending
+	}
+	if time.Now().After(upload.ExpiresAt) {
+		return nil, ErrUploadNotPending
+	}
+	return upload, nil
+}
+
+// keep moves the finished content at tmpPath to its final location, on local
+// disk under name or in the object store, and records where it went on file
+func (s *Service) keep(ctx context.Context, file *domain.File, tmpPath, name string) error {
+	if s.objects == nil {
+		path := filepath.Join(s.config.StorageDir, "files", name)
+		if err := os.Rename(tmpPath, path); err != nil {
+			return fmt.Errorf("store file: %w", err)
+		}
+		file.Storage = domain.FileStorageLocal
+		file.StoragePath = path
+		return nil
+	}
+
+	f, err := os.Open(tmpPath)
+	if err != nil {
+		return fmt.Errorf("store file: %w", err)
+	}
+	defer f.Close()
+	key := "files/" + name
+	if err := s.objects.PutStream(ctx, key, f, file.Bytes, file.MimeType); err != nil {
+		return fmt.Errorf("store file: %w", err)
+	}
+	os.Remove(tmpPath)
+	file.Storage = domain.FileStorageObjectStore
+	file.StoragePath = key
+	return nil
+}
+
+// passThrough uploads the content at path to the files API of the provider
+// serving model
+func (s *Service) passThrough(ctx context.Context, file *domain.File, model, path string) error {
+	if s.clients == nil {
+		return ErrNoPassthrough
+	}
+	client, err := s.clients.GetClientForModel(ctx, model)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidUpload, err)
+	}
+	capable, ok := client.(domain.FileCapable)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNoPassthrough, client.Provider())
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open file: %w", err)
+	}
+	defer f.Close()
+	providerFileID, err := capable.UploadFile(ctx, file.Filename, file.MimeType, file.Bytes, f)
+	if err != nil {
+		return fmt.Errorf("upload to %s: %w", client.Provider(), err)
+	}
+	file.Storage = domain.FileStorageProvider
+	file.Provider = client.Provider()
+	file.ProviderFileID = providerFileID
+	file.Model = model
+	return nil
+}
+
+// openContent opens the stored content of a file
+func (s *Service) openContent(ctx context.Context, file *domain.File) (io.ReadCloser, error) {
+	switch file.Storage {
+	case domain.FileStorageProvider:
+		return nil, fmt.Errorf("%w: file %s is stored with %s", ErrFileUnusable, file.ID, file.Provider)
+	case domain.FileStorageObjectStore:
+		if s.objects == nil {
+			return nil, fmt.Errorf("open file: no object store configured")
+		}
+		content, err := s.objects.Get(ctx, file.StoragePath)
+		if err != nil {
+			return nil, fmt.Errorf("open file: %w", err)
+		}
+		return content, nil
+	default:
+		f, err := os.Open(file.StoragePath)
+		if err != nil {
+			return nil, fmt.Errorf("open file: %w", err)
+		}
+		return f, nil
+	}
+}
+
+// removeContent removes a file's content wherever it is kept. Failures are
+// logged; the file is already unreachable through the gateway.
+func (s *Service) removeContent(ctx context.Context, file *domain.File) {
+	var err error
+	switch file.Storage {
+	case domain.FileStorageProvider:
+		err = ErrNoPassthrough
+		if s.clients != nil {
+			var client domain.LLMClient
+			if client, err = s.clients.GetClientForModel(ctx, file.Model); err == nil {
+				if capable, ok := client.(domain.FileCapable); ok {
+					err = capable.DeleteFile(ctx, file.ProviderFileID)
+				}
+			}
+		}
+	case domain.FileStorageObjectStore:
+		if s.objects != nil {
+			err = s.objects.Delete(ctx, file.StoragePath)
+		}
+	default:
+		if err = os.Remove(file.StoragePath); errors.Is(err, os.ErrNotExist) {
+			err = nil
+		}
+	}
+	if err != nil {
+		slog.Warn("Failed to remove file content", "file_id", file.ID, "storage", file.Storage, "error", err)
+	}
+}
+
+// expiry returns when a new file is deleted by retention, if ever. The role's
+// retention applies when it is shorter than the configured one.
+func (s *Service) expiry(policy *domain.FilePolicy) *time.Time {
+	retention := s.config.Retention
+	if policy.Enabled && policy.RetentionDays > 0 {
+		if days := time.Duration(policy.RetentionDays) * 24 * time.Hour; retention <= 0 || days < retention {
+			retention = days
+		}
+	}
+	if retention <= 0 {
+		return nil
+	}
+	expiresAt := time.Now().Add(retention)
+	return &expiresAt
+}
+
+func (s *Service) modelProvider(ctx context.Context, model string) (domain.Provider, error) {
+	if s.clients == nil {
+		return "", ErrNoPassthrough
+	}
+	client, err := s.clients.GetClientForModel(ctx, model)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrFileUnusable, err)
+	}
+	return client.Provider(), nil
+}
+
+func (s *Service) uploadDir(uploadID string) string {
+	return filepath.Join(s.config.StorageDir, "uploads", uploadID)
+}
+
+func stitchParts(w io.Writer, parts []*domain.UploadPart) error {
+	for _, p := range parts {
+		f, err := os.Open(p.StoragePath)
+		if err != nil {
+			return fmt.Errorf("open part %d: %w", p.PartNumber, err)
+		}
+		_, err = io.Copy(w, f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("copy part %d: %w", p.PartNumber, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,304 @@
+package files
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+)
+
+// memStore is an in-memory Store for tests
+type memStore struct {
+	uploads map[string]*domain.Upload
+	parts   map[string]map[int]*domain.UploadPart
+	files   map[string]*domain.File
+	nextID  int
+}
+
+func newMemStore() *memStore {
+	return &memStore{
+		uploads: make(map[string]*domain.Upload),
+		parts:   make(map[string]map[int]*domain.UploadPart),
+		files:   make(map[string]*domain.File),
+	}
+}
+
+func (m *memStore) id() string {
+	m.nextID++
+	return fmt.Sprintf("id-%d", m.nextID)
+}
+
+func (m *memStore) CreateUpload(_ context.Context, u *domain.Upload) error {
+	u.ID = m.id()
+	copied := *u
+	m.uploads[u.ID] = &copied
+	return nil
+}
+
+func (m *memStore) GetUpload(_ context.Context, id string) (*domain.Upload, error) {
+	u, ok := m.uploads[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *u
+	return &copied, nil
+}
+
+func (m *memStore) UpdateUploadStatus(_ context.Context, id string, status domain.UploadStatus) (bool, error) {
+	u, ok := m.uploads[id]
+	if !ok || u.Status != domain.UploadStatusPending {
+		return false, nil
+	}
+	u.Status = status
+	return true, nil
+}
+
+func (m *memStore) ListExpiredUploads(_ context.Context, now time.Time, _ int) ([]*domain.Upload, error) {
+	var out []*domain.Upload
+	for _, u := range m.uploads {
+		if u.Status == domain.UploadStatusPending && u.ExpiresAt.Before(now) {
+			out = append(out, u)
+		}
+	}
+	return out, nil
+}
+
+func (m *memStore) SaveUploadPart(_ context.Context, p *domain.UploadPart) error {
+	if m.parts[p.UploadID] == nil {
+		m.parts[p.UploadID] = make(map[int]*domain.UploadPart)
+	}
+	m.parts[p.UploadID][p.PartNumber] = p
+	return nil
+}
+
+func (m *memStore) ListUploadParts(_ context.Context, uploadID string) ([]*domain.UploadPart, error) {
+	var out []*domain.UploadPart
+	for _, p := range m.parts[uploadID] {
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+func (m *memStore) CompleteUpload(_ context.Context, uploadID string, f *domain.File) error {
+	f.ID = m.id()
+	m.files[f.ID] = f
+	m.uploads[uploadID].Status = domain.UploadStatusCompleted
+	m.uploads[uploadID].FileID = f.ID
+	return nil
+}
+
+func (m *memStore) CreateFile(_ context.Context, f *domain.File) error {
+	f.ID = m.id()
+	m.files[f.ID] = f
+	return nil
+}
+
+func (m *memStore) GetFile(_ context.Context, id string) (*domain.File, error) {
+	return m.files[id], nil
+}
+
+func (m *memStore) ListFiles(_ context.Context, apiKeyID, purpose string, _ int) ([]*domain.File, error) {
+	var out []*domain.File
+	for _, f := range m.files {
+		if f.Status != domain.FileStatusDeleted && (apiKeyID == "" || f.APIKeyID == apiKeyID) && (purpose == "" || f.Purpose == purpose) {
+			out = append(out, f)
+		}
+	}
+	return out, nil
+}
+
+func (m *memStore) ListExpiredFiles(_ context.Context, now time.Time, _ int) ([]*domain.File, error) {
+	var out []*domain.File
+	for _, f := range m.files {
+		if f.Status != domain.FileStatusDeleted && f.ExpiresAt != nil && f.ExpiresAt.Before(now) {
+			out = append(out, f)
+		}
+	}
+	return out, nil
+}
+
+func (m *memStore) MarkFileDeleted(_ context.Context, id string) (bool, error) {
+	f, ok := m.files[id]
+	if !ok || f.Status == domain.FileStatusDeleted {
+		return false, nil
+	}
+	f.Status = domain.FileStatusDeleted
+	return true, nil
+}
+
+func newTestService(t *testing.T) (*Service, *memStore) {
+	store := newMemStore()
+	return NewService(config.FilesConfig{
+		StorageDir:  t.TempDir(),
+		MaxFileSize: 1024,
+		MaxPartSize: 8,
+		UploadTTL:   time.Hour,
+	}, store), store
+}
+
+func sha(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestResumableUpload(t *testing.T) {
+	svc, _ := newTestService(t)
+	ctx := context.Background()
+	content := "hello, chunked world"
+
+	upload, err := svc.CreateUpload(ctx, CreateUploadRequest{
+		Filename: "data.jsonl",
+		Purpose:  "batch",
+		Bytes:    int64(len(content)),
+		SHA256:   sha(content),
+	})
+	if err != nil {
+		t.Fatalf("CreateUpload failed: %v", err)
+	}
+
+	chunks := []string{content[:8], content[8:16], content[16:]}
+
+	// Send parts out of order, then resend part 2 as a client would after a dropped connection
+	for _, num := range []int{3, 1, 2, 2} {
+		if _, err := svc.PutPart(ctx, upload.ID, num, strings.NewReader(chunks[num-1]), sha(chunks[num-1])); err != nil {
+			t.Fatalf("PutPart %d failed: %v", num, err)
+		}
+	}
+
+	_, parts, err := svc.GetUpload(ctx, upload.ID)
+	if err != nil {
+		t.Fatalf("GetUpload failed: %v", err)
+	}
+	if len(parts) != 3 {
+		t.Fatalf("expected 3 parts, got %d", len(parts))
+	}
+
+	file, err := svc.Complete(ctx, upload.ID, []int{1, 2, 3})
+	if err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	data, err := os.ReadFile(file.StoragePath)
+	if err != nil {
+		t.Fatalf("reading stitched file: %v", err)
+	}
+	if string(data) != content {
+		t.Errorf("stitched content = %q, want %q", data, content)
+	}
+
+	if _, err := svc.PutPart(ctx, upload.ID, 4, strings.NewReader("x"), ""); !errors.Is(err, ErrUploadNotPending) {
+		t.Errorf("PutPart after completion: got %v, want ErrUploadNotPending", err)
+	}
+}
+
+func TestPutPartValidation(t *testing.T) {
+	svc, _ := newTestService(t)
+	ctx := context.Background()
+
+	upload, err := svc.CreateUpload(ctx, CreateUploadRequest{Filename: "f.txt", Purpose: "assistants", Bytes: 20})
+	if err != nil {
+		t.Fatalf("CreateUpload failed: %v", err)
+	}
+
+	if _, err := svc.PutPart(ctx, upload.ID, 1, strings.NewReader("abcd"), sha("other")); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("bad checksum: got %v, want ErrChecksumMismatch", err)
+	}
+	if _, err := svc.PutPart(ctx, upload.ID, 1, strings.NewReader("123456789"), ""); !errors.Is(err, ErrPartTooLarge) {
+		t.Errorf("oversized part: got %v, want ErrPartTooLarge", err)
+	}
+	if _, err := svc.PutPart(ctx, upload.ID, 1, strings.NewReader("abcd"), ""); err != nil {
+		t.Fatalf("PutPart failed: %v", err)
+	}
+	if _, err := svc.Complete(ctx, upload.ID, []int{1}); !errors.Is(err, ErrInvalidUpload) {
+		t.Errorf("short upload: got %v, want ErrInvalidUpload", err)
+	}
+}
+
+func TestCleanupExpired(t *testing.T) {
+	svc, store := newTestService(t)
+	ctx := context.Background()
+
+	upload, err := svc.CreateUpload(ctx, CreateUploadRequest{Filename: "f.txt", Purpose: "assistants", Bytes: 4})
+	if err != nil {
+		t.Fatalf("CreateUpload failed: %v", err)
+	}
+	if _, err := svc.PutPart(ctx, upload.ID, 1, strings.NewReader("ab"), ""); err != nil {
+		t.Fatalf("PutPart failed: %v", err)
+	}
+	store.uploads[upload.ID].ExpiresAt = time.Now().Add(-time.Minute)
+
+	n, err := svc.CleanupExpired(ctx)
+	if err != nil || n != 1 {
+		t.Fatalf("CleanupExpired = %d, %v; want 1, nil", n, err)
+	}
+	if store.uploads[upload.ID].Status != domain.UploadStatusExpired {
+		t.Errorf("status = %s, want expired", store.uploads[upload.ID].Status)
+	}
+	if _, err := os.Stat(svc.uploadDir(upload.ID)); !os.IsNotExist(err) {
+		t.Errorf("expected parts directory to be removed")
+	}
+}
+
+func TestUploadFilePolicy(t *testing.T) {
+	svc, store := newTestService(t)
+	ctx := context.Background()
+	policy := &domain.FilePolicy{
+		Enabled:          true,
+		MaxFileSize:      16,
+		AllowedMimeTypes: []string{"text/*"},
+		RetentionDays:    1,
+	}
+
+	if _, err := svc.UploadFile(ctx, UploadFileRequest{Filename: "photo.png", Purpose: "vision", Policy: policy}, strings.NewReader("png")); !errors.Is(err, ErrFileType) {
+		t.Errorf("disallowed type: got %v, want ErrFileType", err)
+	}
+	if _, err := svc.UploadFile(ctx, UploadFileRequest{Filename: "notes.txt", Purpose: "user_data", Policy: policy}, strings.NewReader(strings.Repeat("x", 17))); !errors.Is(err, ErrFileTooLarge) {
+		t.Errorf("oversized file: got %v, want ErrFileTooLarge", err)
+	}
+
+	file, err := svc.UploadFile(ctx, UploadFileRequest{Filename: "notes.txt", Purpose: "user_data", APIKeyID: "key-1", Policy: policy}, strings.NewReader("hello"))
+	if err != nil {
+		t.Fatalf("UploadFile failed: %v", err)
+	}
+	if !strings.HasPrefix(file.MimeType, "text/plain") || file.Storage != domain.FileStorageLocal {
+		t.Errorf("got mime type %q in %q storage, want text/plain on local disk", file.MimeType, file.Storage)
+	}
+	if file.ExpiresAt == nil || time.Until(*file.ExpiresAt) > 24*time.Hour {
+		t.Errorf("expires at %v, want within a day", file.ExpiresAt)
+	}
+
+	req := &domain.ChatRequest{Messages: []domain.Message{{
+		Role:    "user",
+		Content: []domain.ContentBlock{{Type: "file", FileID: file.ID}},
+	}}}
+	if err := svc.ResolveContent(ctx, req, "key-2"); !errors.Is(err, ErrFileNotFound) {
+		t.Errorf("another key's file: got %v, want ErrFileNotFound", err)
+	}
+	if err := svc.ResolveContent(ctx, req, "key-1"); err != nil {
+		t.Fatalf("ResolveContent failed: %v", err)
+	}
+	if block := req.Messages[0].Content[0]; string(block.FileData) != "hello" || block.Filename != "notes.txt" {
+		t.Errorf("resolved block = %+v", block)
+	}
+
+	// Retention deletes the file and its content
+	expired := time.Now().Add(-time.Minute)
+	store.files[file.ID].ExpiresAt = &expired
+	n, err := svc.CleanupExpiredFiles(ctx)
+	if err != nil || n != 1 {
+		t.Fatalf("CleanupExpiredFiles = %d, %v; want 1, nil", n, err)
+	}
+	if _, err := os.Stat(file.StoragePath); !os.IsNotExist(err) {
+		t.Errorf("expected file content to be removed")
+	}
+	if got, _ := svc.GetFile(ctx, file.ID); got != nil {
+		t.Errorf("deleted file is still returned")
+	}
+}
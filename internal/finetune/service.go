@@ -0,0 +1,380 @@
+// Package finetune runs fine-tuning jobs through providers' fine-tuning APIs
+// and keeps a local record of each job for listing and auditing.
+package finetune
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+)
+
+// Errors returned by the service. HTTP handlers map these to status codes.
+var (
+	ErrJobNotFound       = errors.New("fine-tuning job not found")
+	ErrInvalidJob        = errors.New("invalid fine-tuning job")
+	ErrNotSupported      = errors.New("provider does not support fine-tuning")
+	ErrJobNotCancellable = errors.New("fine-tuning job cannot be cancelled")
+)
+
+// filePurpose is the purpose training and validation files are uploaded with
+const filePurpose = "fine-tune"
+
+// Store is the persistence used by the service (implemented by postgres.TenantStore)
+type Store interface {
+	CreateFineTuneJob(ctx context.Context, job *domain.FineTuneJob) error
+	UpdateFineTuneJob(ctx context.Context, job *domain.FineTuneJob) error
+	GetFineTuneJob(ctx context.Context, id string) (*domain.FineTuneJob, error)
+	ListFineTuneJobs(ctx context.Context, apiKeyID string, limit int) ([]*domain.FineTuneJob, error)
+	ListActiveFineTuneJobs(ctx context.Context) ([]*domain.FineTuneJob, error)
+	CreateAuditLog(ctx context.Context, entry *domain.AuditLog) error
+}
+
+// ClientResolver returns the provider client that serves a model (implemented by gateway.Service)
+type ClientResolver interface {
+	GetClientForModel(ctx context.Context, model string) (domain.LLMClient, error)
+}
+
+// FileReader reads files uploaded to the gateway (implemented by files.Service)
+type FileReader interface {
+	GetFile(ctx context.Context, id string) (*domain.File, error)
+	OpenFile(ctx context.Context, id string) (*domain.File, io.ReadCloser, error)
+}
+
+// Actor is who created or cancelled a job, for the audit log. Without an
+// API key it is an admin.
+type Actor struct {
+	APIKeyID  string
+	IPAddress string
+	UserAgent string
+}
+
+// CreateRequest describes a new job. The files are gateway file IDs.
+type CreateRequest struct {
+	Model            string
+	TrainingFileID   string
+	ValidationFileID string
+	Hyperparameters  map[string]any
+	Suffix           string
+	Metadata         map[string]any
+}
+
+// Service starts fine-tuning jobs at providers and polls them
+type Service struct {
+	config  atomic.Pointer[config.Config]
+	store   Store
+	clients ClientResolver
+	files   FileReader
+}
+
+// NewService creates a new fine-tuning service
+func NewService(cfg *config.Config, store Store, clients ClientResolver, files FileReader) *Service {
+	s := &Service{store: store, clients: clients, files: files}
+	s.config.Store(cfg)
+	return s
+}
+
+// SetConfig swaps in a reloaded configuration; a new poll interval applies after the next poll
+func (s *Service) SetConfig(cfg *config.Config) {
+	s.config.Store(cfg)
+}
+
+// Create uploads the training files to the provider serving the model and
+// starts a job there. The files must have been uploaded by the actor's API
+// key with purpose "fine-tune".
+func (s *Service) Create(ctx context.Context, req CreateRequest, actor Actor) (*domain.FineTuneJob, error) {
+	if req.Model == "" || req.TrainingFileID == "" {
+		return nil, fmt.Errorf("%w: model and training_file are required", ErrInvalidJob)
+	}
+	if len(req.Suffix) > 64 {
+		return nil, fmt.Errorf("%w: suffix is longer than 64 characters", ErrInvalidJob)
+	}
+
+	providerType, ok := s.config.Load().GetProviderForModel(req.Model)
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown provider for model %s", ErrInvalidJob, req.Model)
+	}
+	client, err := s.clients.GetClientForModel(ctx, req.Model)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidJob, err)
+	}
+	ftClient, ok := client.(domain.FineTuneCapable)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotSupported, providerType)
+	}
+
+	job := &domain.FineTuneJob{
+		APIKeyID:         actor.APIKeyID,
+		Provider:         providerType,
+		Model:            req.Model,
+		TrainingFileID:   req.TrainingFileID,
+		ValidationFileID: req.ValidationFileID,
+		Hyperparameters:  req.Hyperparameters,
+		Suffix:           req.Suffix,
+		Status:           domain.FineTuneStatusValidatingFiles,
+		Metadata:         req.Metadata,
+	}
+
+	// Check both files before uploading either
+	for _, id := range []string{req.TrainingFileID, req.ValidationFileID} {
+		if id == "" {
+			continue
+		}
+		if err := s.checkFile(ctx, id, actor.APIKeyID); err != nil {
+			return nil, err
+		}
+	}
+
+	providerReq := domain.FineTuneRequest{
+		Model:           req.Model,
+		Hyperparameters: req.Hyperparameters,
+		Suffix:          req.Suffix,
+	}
+	providerReq.TrainingFile, err = s.upload(ctx, ftClient, req.TrainingFileID)
+	if err == nil && req.ValidationFileID != "" {
+		providerReq.ValidationFile, err = s.upload(ctx, ftClient, req.ValidationFileID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.store.CreateFineTuneJob(ctx, job); err != nil {
+		return nil, fmt.Errorf("create fine-tuning job: %w", err)
+	}
+
+	providerJobID, err := ftClient.CreateFineTuneJob(ctx, providerReq)
+	if err != nil {
+		slog.Error("Failed to start fine-tuning job at provider", "job_id", job.ID, "provider", providerType, "error", err)
+		now := time.Now()
+		job.Status = domain.FineTuneStatusFailed
+		job.Error = err.Error()
+		job.FinishedAt = &now
+	} else {
+		job.ProviderJobID = providerJobID
+	}
+	if err := s.store.UpdateFineTuneJob(ctx, job); err != nil {
+		return nil, fmt.Errorf("update fine-tuning job: %w", err)
+	}
+	s.audit(ctx, domain.AuditActionCreate, job, actor, map[string]any{
+		"model":           job.Model,
+		"training_file":   job.TrainingFileID,
+		"validation_file": job.ValidationFileID,
+		"provider_job_id": job.ProviderJobID,
+	})
+	return job, nil
+}
+
+// Get returns a job
+func (s *Service) Get(ctx context.Context, id string) (*domain.FineTuneJob, error) {
+	job, err := s.store.GetFineTuneJob(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("get fine-tuning job: %w", err)
+	}
+	if job == nil {
+		return nil, ErrJobNotFound
+	}
+	return job, nil
+}
+
+// List returns the most recent jobs. If apiKeyID is set, only that key's jobs are returned.
+func (s *Service) List(ctx context.Context, apiKeyID string, limit int) ([]*domain.FineTuneJob, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	return s.store.ListFineTuneJobs(ctx, apiKeyID, limit)
+}
+
+// Cancel asks the provider to stop a job that has not finished
+func (s *Service) Cancel(ctx context.Context, id string, actor Actor) (*domain.FineTuneJob, error) {
+	job, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status.IsTerminal() || job.ProviderJobID == "" {
+		return nil, fmt.Errorf("%w: status is %s", ErrJobNotCancellable, job.Status)
+	}
+
+	ftClient, err := s.client(ctx, job)
+	if err != nil {
+		return nil, err
+	}
+	if err := ftClient.CancelFineTuneJob(ctx, job.ProviderJobID); err != nil {
+		return nil, fmt.Errorf("cancel provider fine-tuning job: %w", err)
+	}
+
+	now := time.Now()
+	job.Status = domain.FineTuneStatusCancelled
+	job.FinishedAt = &now
+	if err := s.store.UpdateFineTuneJob(ctx, job); err != nil {
+		return nil, fmt.Errorf("update fine-tuning job: %w", err)
+	}
+	s.audit(ctx, domain.AuditActionUpdate, job, actor, map[string]any{
+		"status":          job.Status,
+		"provider_job_id": job.ProviderJobID,
+	})
+	return job, nil
+}
+
+// Poll refreshes every unfinished job from its provider. Returns the number of jobs that finished.
+func (s *Service) Poll(ctx context.Context) (int, error) {
+	jobs, err := s.store.ListActiveFineTuneJobs(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("list active fine-tuning jobs: %w", err)
+	}
+
+	finished := 0
+	for _, job := range jobs {
+		done, err := s.refresh(ctx, job)
+		if err != nil {
+			slog.Warn("Failed to refresh fine-tuning job", "job_id", job.ID, "provider", job.Provider, "error", err)
+			continue
+		}
+		if done {
+			finished++
+		}
+	}
+	return finished, nil
+}
+
+// StartPoller periodically polls unfinished jobs until ctx is cancelled
+func (s *Service) StartPoller(ctx context.Context) {
+	interval := s.pollInterval()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				n, err := s.Poll(ctx)
+				if err != nil {
+					slog.Error("Failed to poll fine-tuning jobs", "error", err)
+				} else if n > 0 {
+					slog.Info("Fine-tuning jobs finished", "count", n)
+				}
+				// Pick up a reloaded interval
+				if next := s.pollInterval(); next != interval {
+					interval = next
+					ticker.Reset(interval)
+				}
+			}
+		}
+	}()
+}
+
+func (s *Service) pollInterval() time.Duration {
+	if interval := s.config.Load().FineTuning.PollInterval; interval > 0 {
+		return interval
+	}
+	return time.Minute
+}
+
+// refresh updates one job from the provider and reports whether it finished
+func (s *Service) refresh(ctx context.Context, job *domain.FineTuneJob) (bool, error) {
+	ftClient, err := s.client(ctx, job)
+	if err != nil {
+		return false, err
+	}
+	status, err := ftClient.GetFineTuneJob(ctx, job.ProviderJobID)
+	if err != nil {
+		return false, err
+	}
+
+	job.Status = status.Status
+	job.FineTunedModel = status.FineTunedModel
+	job.TrainedTokens = status.TrainedTokens
+	job.Error = status.Error
+	done := status.Status.IsTerminal()
+	if done {
+		now := time.Now()
+		job.FinishedAt = &now
+	}
+	if err := s.store.UpdateFineTuneJob(ctx, job); err != nil {
+		return false, err
+	}
+	if done {
+		s.audit(ctx, domain.AuditActionUpdate, job, Actor{}, map[string]any{
+			"status":           job.Status,
+			"fine_tuned_model": job.FineTunedModel,
+			"trained_tokens":   job.TrainedTokens,
+		})
+	}
+	return done, nil
+}
+
+// checkFile makes sure a file exists, belongs to the API key and is meant for fine-tuning
+func (s *Service) checkFile(ctx context.Context, id, apiKeyID string) error {
+	file, err := s.files.GetFile(ctx, id)
+	if err != nil {
+		return fmt.Errorf("get file: %w", err)
+	}
+	if file == nil || (file.APIKeyID != "" && apiKeyID != "" && file.APIKeyID != apiKeyID) {
+		return fmt.Errorf("%w: file %s not found", ErrInvalidJob, id)
+	}
+	if file.Purpose != filePurpose {
+		return fmt.Errorf("%w: file %s was uploaded with purpose %q, not %q", ErrInvalidJob, id, file.Purpose, filePurpose)
+	}
+	return nil
+}
+
+// upload sends a gateway file to the provider and returns the provider's file ID
+func (s *Service) upload(ctx context.Context, ftClient domain.FineTuneCapable, id string) (string, error) {
+	file, content, err := s.files.OpenFile(ctx, id)
+	if err != nil {
+		return "", fmt.Errorf("%w: file %s: %v", ErrInvalidJob, id, err)
+	}
+	defer content.Close()
+	providerFileID, err := ftClient.UploadTrainingFile(ctx, file.Filename, content)
+	if err != nil {
+		return "", fmt.Errorf("upload file %s to provider: %w", id, err)
+	}
+	return providerFileID, nil
+}
+
+func (s *Service) client(ctx context.Context, job *domain.FineTuneJob) (domain.FineTuneCapable, error) {
+	client, err := s.clients.GetClientForModel(ctx, job.Model)
+	if err != nil {
+		return nil, fmt.Errorf("get client: %w", err)
+	}
+	ftClient, ok := client.(domain.FineTuneCapable)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotSupported, job.Provider)
+	}
+	return ftClient, nil
+}
+
+// audit records a job change in the audit log. Without an API key or an IP
+// address the change came from the poller.
+func (s *Service) audit(ctx context.Context, action domain.AuditAction, job *domain.FineTuneJob, actor Actor, details map[string]any) {
+	entry := &domain.AuditLog{
+		Action:       action,
+		ResourceType: domain.AuditResourceFineTuneJob,
+		ResourceID:   job.ID,
+		ResourceName: job.Model,
+		ActorID:      actor.APIKeyID,
+		ActorType:    "api_key",
+		IPAddress:    actor.IPAddress,
+		UserAgent:    actor.UserAgent,
+		Details:      details,
+		Status:       "success",
+	}
+	switch {
+	case actor.APIKeyID != "":
+	case actor.IPAddress != "":
+		entry.ActorType = "admin"
+	default:
+		entry.ActorID = "system"
+		entry.ActorType = "system"
+	}
+	if err := s.store.CreateAuditLog(context.WithoutCancel(ctx), entry); err != nil {
+		slog.Error("Failed to audit fine-tuning job", "job_id", job.ID, "error", err)
+	}
+}
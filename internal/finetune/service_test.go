@@ -0,0 +1,192 @@
+package finetune
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+)
+
+// fakeClient is a fine-tuning capable provider client for tests
+type fakeClient struct {
+	domain.LLMClient
+	uploaded  []string
+	req       domain.FineTuneRequest
+	status    *domain.ProviderFineTuneJob
+	cancelled bool
+}
+
+func (c *fakeClient) UploadTrainingFile(_ context.Context, filename string, content io.Reader) (string, error) {
+	data, _ := io.ReadAll(content)
+	c.uploaded = append(c.uploaded, string(data))
+	return "provider-" + filename, nil
+}
+
+func (c *fakeClient) CreateFineTuneJob(_ context.Context, req domain.FineTuneRequest) (string, error) {
+	c.req = req
+	return "ftjob-1", nil
+}
+
+func (c *fakeClient) GetFineTuneJob(context.Context, string) (*domain.ProviderFineTuneJob, error) {
+	return c.status, nil
+}
+
+func (c *fakeClient) CancelFineTuneJob(context.Context, string) error {
+	c.cancelled = true
+	return nil
+}
+
+type fakeResolver struct{ client domain.LLMClient }
+
+func (r fakeResolver) GetClientForModel(context.Context, string) (domain.LLMClient, error) {
+	return r.client, nil
+}
+
+// memStore is an in-memory Store for tests
+type memStore struct {
+	jobs   map[string]*domain.FineTuneJob
+	audits []*domain.AuditLog
+}
+
+func (m *memStore) CreateFineTuneJob(_ context.Context, job *domain.FineTuneJob) error {
+	job.ID = "job-1"
+	m.jobs[job.ID] = job
+	return nil
+}
+
+func (m *memStore) UpdateFineTuneJob(_ context.Context, job *domain.FineTuneJob) error {
+	m.jobs[job.ID] = job
+	return nil
+}
+
+func (m *memStore) GetFineTuneJob(_ context.Context, id string) (*domain.FineTuneJob, error) {
+	return m.jobs[id], nil
+}
+
+func (m *memStore) ListFineTuneJobs(context.Context, string, int) ([]*domain.FineTuneJob, error) {
+	return nil, nil
+}
+
+func (m *memStore) ListActiveFineTuneJobs(context.Context) ([]*domain.FineTuneJob, error) {
+	var out []*domain.FineTuneJob
+	for _, job := range m.jobs {
+		if !job.Status.IsTerminal() && job.ProviderJobID != "" {
+			out = append(out, job)
+		}
+	}
+	return out, nil
+}
+
+func (m *memStore) CreateAuditLog(_ context.Context, entry *domain.AuditLog) error {
+	m.audits = append(m.audits, entry)
+	return nil
+}
+
+type memFiles map[string]*domain.File
+
+func (f memFiles) GetFile(_ context.Context, id string) (*domain.File, error) {
+	return f[id], nil
+}
+
+func (f memFiles) OpenFile(_ context.Context, id string) (*domain.File, io.ReadCloser, error) {
+	file := f[id]
+	if file == nil {
+		return nil, nil, errors.New("file not found")
+	}
+	return file, io.NopCloser(strings.NewReader("content of " + id)), nil
+}
+
+func newTestService() (*Service, *fakeClient, *memStore) {
+	cfg := &config.Config{Models: map[string]config.ModelConfig{
+		"openai/gpt-4o-mini": {Provider: "openai"},
+	}}
+	client := &fakeClient{}
+	store := &memStore{jobs: make(map[string]*domain.FineTuneJob)}
+	files := memFiles{
+		"train": {ID: "train", APIKeyID: "key-1", Filename: "train.jsonl", Purpose: "fine-tune"},
+		"valid": {ID: "valid", APIKeyID: "key-1", Filename: "valid.jsonl", Purpose: "fine-tune"},
+		"other": {ID: "other", APIKeyID: "key-2", Filename: "other.jsonl", Purpose: "fine-tune"},
+		"chat":  {ID: "chat", APIKeyID: "key-1", Filename: "notes.pdf", Purpose: "user_data"},
+	}
+	return NewService(cfg, store, fakeResolver{client}, files), client, store
+}
+
+func TestJobLifecycle(t *testing.T) {
+	ctx := context.Background()
+	svc, client, store := newTestService()
+	actor := Actor{APIKeyID: "key-1", IPAddress: "10.0.0.1"}
+
+	job, err := svc.Create(ctx, CreateRequest{
+		Model:            "openai/gpt-4o-mini",
+		TrainingFileID:   "train",
+		ValidationFileID: "valid",
+		Hyperparameters:  map[string]any{"n_epochs": 3},
+		Suffix:           "support",
+	}, actor)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if job.ProviderJobID != "ftjob-1" || job.Status != domain.FineTuneStatusValidatingFiles || job.APIKeyID != "key-1" {
+		t.Fatalf("unexpected job after create: %+v", job)
+	}
+	if client.req.TrainingFile != "provider-train.jsonl" || client.req.ValidationFile != "provider-valid.jsonl" || client.req.Suffix != "support" {
+		t.Errorf("unexpected provider request: %+v", client.req)
+	}
+	if len(client.uploaded) != 2 || client.uploaded[0] != "content of train" {
+		t.Errorf("uploaded = %q", client.uploaded)
+	}
+
+	client.status = &domain.ProviderFineTuneJob{Status: domain.FineTuneStatusRunning}
+	if n, err := svc.Poll(ctx); err != nil || n != 0 {
+		t.Fatalf("Poll = %d, %v; want 0, nil", n, err)
+	}
+	client.status = &domain.ProviderFineTuneJob{Status: domain.FineTuneStatusSucceeded, FineTunedModel: "ft:gpt-4o-mini:acme:support:abc", TrainedTokens: 1200}
+	if n, err := svc.Poll(ctx); err != nil || n != 1 {
+		t.Fatalf("Poll = %d, %v; want 1, nil", n, err)
+	}
+	job = store.jobs["job-1"]
+	if job.Status != domain.FineTuneStatusSucceeded || job.FineTunedModel == "" || job.TrainedTokens != 1200 || job.FinishedAt == nil {
+		t.Errorf("unexpected job after poll: %+v", job)
+	}
+	if _, err := svc.Cancel(ctx, "job-1", actor); !errors.Is(err, ErrJobNotCancellable) {
+		t.Errorf("cancelling a finished job: got %v", err)
+	}
+
+	// Created by the key, finished by the poller
+	if len(store.audits) != 2 || store.audits[0].ActorType != "api_key" || store.audits[1].ActorType != "system" {
+		t.Errorf("unexpected audit entries: %d", len(store.audits))
+	}
+}
+
+func TestCancel(t *testing.T) {
+	ctx := context.Background()
+	svc, client, _ := newTestService()
+	if _, err := svc.Create(ctx, CreateRequest{Model: "openai/gpt-4o-mini", TrainingFileID: "train"}, Actor{APIKeyID: "key-1"}); err != nil {
+		t.Fatal(err)
+	}
+	job, err := svc.Cancel(ctx, "job-1", Actor{APIKeyID: "key-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !client.cancelled || job.Status != domain.FineTuneStatusCancelled {
+		t.Errorf("cancelled=%v status=%s", client.cancelled, job.Status)
+	}
+}
+
+func TestCreateRejectsFiles(t *testing.T) {
+	ctx := context.Background()
+	svc, client, _ := newTestService()
+	for _, id := range []string{"missing", "other", "chat"} {
+		_, err := svc.Create(ctx, CreateRequest{Model: "openai/gpt-4o-mini", TrainingFileID: id}, Actor{APIKeyID: "key-1"})
+		if !errors.Is(err, ErrInvalidJob) {
+			t.Errorf("training file %s: got %v, want ErrInvalidJob", id, err)
+		}
+	}
+	if len(client.uploaded) != 0 {
+		t.Errorf("rejected files were uploaded: %q", client.uploaded)
+	}
+}
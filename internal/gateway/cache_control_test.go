@@ -0,0 +1,35 @@
+package gateway
+
+import (
+	"testing"
+
+	"modelgate/internal/cache/semantic"
+	"modelgate/internal/domain"
+)
+
+func TestCacheControlHonoredPerRolePolicy(t *testing.T) {
+	s := &Service{semanticCache: &semantic.TenantAwareService{}}
+
+	tests := []struct {
+		name          string
+		allowBypass   bool
+		control       domain.CacheControl
+		lookup, store bool
+	}{
+		{"no directive", true, "", true, true},
+		{"bypass allowed", true, domain.CacheControlBypass, false, false},
+		{"refresh allowed", true, domain.CacheControlRefresh, false, true},
+		{"bypass not allowed", false, domain.CacheControlBypass, true, true},
+		{"refresh not allowed", false, domain.CacheControlRefresh, true, true},
+	}
+	for _, tt := range tests {
+		policy := &domain.RolePolicy{CachingPolicy: domain.CachingPolicy{Enabled: true, AllowBypass: tt.allowBypass}}
+		req := &domain.ChatRequest{CacheControl: tt.control}
+		if got := s.cacheLookupEnabled(policy, req); got != tt.lookup {
+			t.Errorf("%s: lookup = %v, want %v", tt.name, got, tt.lookup)
+		}
+		if got := s.cacheStoreEnabled(policy, req); got != tt.store {
+			t.Errorf("%s: store = %v, want %v", tt.name, got, tt.store)
+		}
+	}
+}
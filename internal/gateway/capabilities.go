@@ -0,0 +1,204 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"modelgate/internal/domain"
+	"modelgate/internal/provider"
+)
+
+// contextLimitMargin is how far a request's token estimate must exceed a
+// model's context window before it is refused up front. The estimate is
+// rough, so borderline requests are left for the provider to judge.
+const contextLimitMargin = 1.25
+
+// checkCapabilities refuses a request its model is known not to accept
+// (images sent to a text-only model, tools to a model without tool use, ...)
+// before it reaches the provider. When the role's routing policy allows it,
+// the request is moved to a capable model from the policy instead. It returns
+// true if the model was switched.
+func (s *Service) checkCapabilities(ctx context.Context, req *domain.ChatRequest, rolePolicy *domain.RolePolicy) (bool, *domain.ProviderError) {
+	code, what := missingCapability(req, s.modelCapabilities(ctx, req.Model))
+	if code == "" {
+		return false, nil
+	}
+
+	// Replays are pinned to the model the original request used
+	var alternative string
+	if s.isRoutingEnabled(rolePolicy) && req.ReplayOf == "" {
+		for _, candidate := range s.router.Alternatives(rolePolicy.RoutingPolicy, rolePolicy.ModelRestriction) {
+			candidate = s.config.Load().ResolveModel(candidate)
+			caps := s.modelCapabilities(ctx, candidate)
+			if code == domain.ErrorCodeContextLength && caps.ContextLimit == 0 {
+				continue
+			}
+			if candidate != req.Model && satisfiesRequest(req, caps) {
+				alternative = candidate
+				break
+			}
+		}
+	}
+
+	if alternative != "" && rolePolicy.RoutingPolicy.CapabilityFallback {
+		slog.Info("Rerouted request to a model that supports it",
+			"original", req.Model,
+			"selected", alternative,
+			"missing", what,
+			"request_id", req.RequestID)
+		req.RoutingDecision = &domain.RoutingDecision{
+			Strategy: rolePolicy.RoutingPolicy.Strategy,
+			Model:    alternative,
+			Reason:   fmt.Sprintf("rerouted: %s does not support %s", req.Model, what),
+		}
+		req.Model = alternative
+		return true, nil
+	}
+
+	providerType, _ := s.config.Load().GetProviderForModel(req.Model)
+	message := fmt.Sprintf("model %s does not support %s", req.Model, what)
+	switch code {
+	case domain.ErrorCodeContextLength:
+		message = fmt.Sprintf("request is about %d tokens, more than the %d-token context window of model %s; shorten the conversation or use a model with a larger context window",
+			estimateRequestTokens(req), s.modelCapabilities(ctx, req.Model).ContextLimit, req.Model)
+	case domain.ErrorCodeVisionNotSupported:
+		message += "; remove the images or use a vision-capable model"
+	case domain.ErrorCodeToolsNotSupported:
+		message += "; remove the tools or use a model with tool calling"
+	case domain.ErrorCodeReasoningNotSupported:
+		message += "; disable reasoning or use a reasoning model"
+	case domain.ErrorCodeGrammarNotSupported:
+		message += "; use json_schema or a model whose provider supports grammars"
+	}
+	if alternative != "" {
+		message += fmt.Sprintf(" such as %s", alternative)
+	}
+	return false, domain.NewProviderError(providerType, code, message)
+}
+
+// modelCapabilities combines what is known about a model: the bundled table
+// of well-known models, the provider's model list and the config file, each
+// overriding the one before. Provider lists leave most flags unset, so only
+// the capabilities they report are taken from them.
+func (s *Service) modelCapabilities(ctx context.Context, model string) domain.ModelCapabilities {
+	caps := provider.KnownCapabilities(model)
+	if providerType, ok := s.config.Load().GetProviderForModel(model); ok {
+		caps.Grammar = boolPtr(providerType.SupportsGrammar())
+	}
+	if am := s.availableModel(ctx, model); am != nil {
+		if am.SupportsTools {
+			caps.Tools = boolPtr(true)
+		}
+		if am.SupportsVision {
+			caps.Vision = boolPtr(true)
+		}
+		if am.SupportsReasoning {
+			caps.Reasoning = boolPtr(true)
+		}
+		caps.ContextLimit = am.ContextWindow
+	}
+	if m, ok := s.config.Load().Models[model]; ok {
+		if m.SupportsTools {
+			caps.Tools = boolPtr(true)
+		}
+		if m.SupportsReasoning {
+			caps.Reasoning = boolPtr(true)
+		}
+		if m.SupportsVision != nil {
+			caps.Vision = m.SupportsVision
+		}
+		if m.ContextLimit > 0 {
+			caps.ContextLimit = int(m.ContextLimit)
+		}
+	}
+	return caps
+}
+
+// DescribeModel completes a model's catalog entry, as listed by its provider,
+// the way requests see it: capabilities are merged with the bundled table and
+// the config file as in modelCapabilities, and prices are those requests are
+// billed at
+func (s *Service) DescribeModel(ctx context.Context, m domain.ModelInfo) domain.ModelInfo {
+	known := provider.KnownCapabilities(m.ID)
+	m.SupportsTools = m.SupportsTools || (known.Tools != nil && *known.Tools)
+	m.SupportsVision = m.SupportsVision || (known.Vision != nil && *known.Vision)
+	m.SupportsReasoning = m.SupportsReasoning || (known.Reasoning != nil && *known.Reasoning)
+	if cfg, ok := s.config.Load().Models[m.ID]; ok {
+		m.SupportsTools = m.SupportsTools || cfg.SupportsTools
+		m.SupportsReasoning = m.SupportsReasoning || cfg.SupportsReasoning
+		if cfg.SupportsVision != nil {
+			m.SupportsVision = *cfg.SupportsVision
+		}
+		if cfg.ContextLimit > 0 {
+			m.ContextLimit = cfg.ContextLimit
+		}
+		if cfg.OutputLimit > 0 {
+			m.OutputLimit = cfg.OutputLimit
+		}
+	}
+	if price := s.PriceFor(ctx, m.ID); price != nil {
+		m.InputCostPer1M = price.InputCostPer1M
+		m.OutputCostPer1M = price.OutputCostPer1M
+		m.CachedInputCostPer1M = price.CachedInputCostPer1M
+	}
+	return m
+}
+
+// missingCapability returns the error code and a description of the first
+// thing the request needs that the model is known to lack, or "" if nothing is
+func missingCapability(req *domain.ChatRequest, caps domain.ModelCapabilities) (code, what string) {
+	switch {
+	case len(req.Tools) > 0 && isFalse(caps.Tools):
+		return domain.ErrorCodeToolsNotSupported, "tool calling"
+	case hasImageInput(req) && isFalse(caps.Vision):
+		return domain.ErrorCodeVisionNotSupported, "image inputs"
+	case wantsReasoning(req) && isFalse(caps.Reasoning):
+		return domain.ErrorCodeReasoningNotSupported, "reasoning"
+	case wantsGrammar(req) && isFalse(caps.Grammar):
+		return domain.ErrorCodeGrammarNotSupported, "grammar-constrained output"
+	case caps.ContextLimit > 0 && float64(estimateRequestTokens(req)) > float64(caps.ContextLimit)*contextLimitMargin:
+		return domain.ErrorCodeContextLength, "prompts this long"
+	}
+	return "", ""
+}
+
+// satisfiesRequest reports whether a model is known to support everything a
+// request needs; a reroute never goes to a model that merely might
+func satisfiesRequest(req *domain.ChatRequest, caps domain.ModelCapabilities) bool {
+	switch {
+	case len(req.Tools) > 0 && !isTrue(caps.Tools):
+		return false
+	case hasImageInput(req) && !isTrue(caps.Vision):
+		return false
+	case wantsReasoning(req) && !isTrue(caps.Reasoning):
+		return false
+	case wantsGrammar(req) && !isTrue(caps.Grammar):
+		return false
+	}
+	return caps.ContextLimit == 0 || estimateRequestTokens(req) <= caps.ContextLimit
+}
+
+func hasImageInput(req *domain.ChatRequest) bool {
+	for _, msg := range req.Messages {
+		for _, block := range msg.Content {
+			if block.Type == "image" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func wantsReasoning(req *domain.ChatRequest) bool {
+	return req.ReasoningConfig != nil && req.ReasoningConfig.Enabled
+}
+
+func wantsGrammar(req *domain.ChatRequest) bool {
+	return req.ResponseFormat != nil && req.ResponseFormat.Type == domain.ResponseFormatGrammar
+}
+
+func isTrue(flag *bool) bool  { return flag != nil && *flag }
+func isFalse(flag *bool) bool { return flag != nil && !*flag }
+
+func boolPtr(v bool) *bool { return &v }
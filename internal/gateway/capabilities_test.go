@@ -0,0 +1,83 @@
+package gateway
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+	"modelgate/internal/routing"
+	"modelgate/internal/routing/health"
+)
+
+func TestCheckCapabilities(t *testing.T) {
+	ctx := context.Background()
+	s := &Service{router: routing.NewRouter(health.NewTracker(nil))}
+	cfg := config.Default()
+	yes := true
+	cfg.Models = map[string]config.ModelConfig{
+		"ollama/llava-custom": {Provider: "ollama", SupportsVision: &yes},
+	}
+	s.config.Store(cfg)
+
+	withImage := func(model string) *domain.ChatRequest {
+		return &domain.ChatRequest{Model: model, Messages: []domain.Message{{
+			Role:    "user",
+			Content: []domain.ContentBlock{{Type: "text", Text: "What is this?"}, {Type: "image"}},
+		}}}
+	}
+
+	// Unknown capabilities are let through
+	if switched, err := s.checkCapabilities(ctx, withImage("ollama/some-model"), nil); switched || err != nil {
+		t.Fatalf("expected an unknown model to pass, got %v, %v", switched, err)
+	}
+
+	// Known gaps are refused with an actionable code
+	_, err := s.checkCapabilities(ctx, withImage("deepseek/deepseek-chat"), nil)
+	if err == nil || err.Code != domain.ErrorCodeVisionNotSupported || err.Status != 400 {
+		t.Fatalf("expected vision_not_supported, got %+v", err)
+	}
+	req := &domain.ChatRequest{
+		Model:    "openai/o1-mini",
+		Messages: []domain.Message{{Role: "user", Content: []domain.ContentBlock{{Type: "text", Text: "hi"}}}},
+		Tools:    []domain.Tool{{Type: "function"}},
+	}
+	if _, err := s.checkCapabilities(ctx, req, nil); err == nil || err.Code != domain.ErrorCodeToolsNotSupported {
+		t.Fatalf("expected tools_not_supported, got %+v", err)
+	}
+
+	// With capability fallback the request moves to a capable model from the policy
+	policy := &domain.RolePolicy{RoutingPolicy: domain.RoutingPolicy{
+		Enabled:       true,
+		Strategy:      domain.RoutingStrategyCost,
+		CostConfig:    &domain.CostRoutingConfig{ComplexModels: []string{"deepseek/deepseek-reasoner", "ollama/llava-custom"}},
+		LatencyConfig: &domain.LatencyRoutingConfig{PreferredModels: []string{"openai/gpt-4o"}},
+	}}
+	req = withImage("deepseek/deepseek-chat")
+	if _, err := s.checkCapabilities(ctx, req, policy); err == nil || !strings.Contains(err.Message, "such as ollama/llava-custom") || req.Model != "deepseek/deepseek-chat" {
+		t.Fatalf("expected a refusal naming an alternative without fallback, got %+v", err)
+	}
+	policy.RoutingPolicy.CapabilityFallback = true
+	switched, err := s.checkCapabilities(ctx, req, policy)
+	if !switched || err != nil || req.Model != "ollama/llava-custom" || req.RoutingDecision == nil {
+		t.Fatalf("expected a reroute to the configured vision model, got %v, %+v, model %s", switched, err, req.Model)
+	}
+
+	// Grammars are only honoured by providers that support them
+	withGrammar := func(model string) *domain.ChatRequest {
+		return &domain.ChatRequest{
+			Model:          model,
+			Messages:       []domain.Message{{Role: "user", Content: []domain.ContentBlock{{Type: "text", Text: "yes or no?"}}}},
+			ResponseFormat: &domain.ResponseFormat{Type: domain.ResponseFormatGrammar, Grammar: `root ::= "yes" | "no"`},
+		}
+	}
+	if _, err := s.checkCapabilities(ctx, withGrammar("openai/gpt-4o"), nil); err == nil || err.Code != domain.ErrorCodeGrammarNotSupported {
+		t.Fatalf("expected grammar_not_supported, got %+v", err)
+	}
+	policy.RoutingPolicy.CostConfig.ComplexModels = []string{"openai/gpt-4o-mini", "fireworks/llama-v3p3-70b-instruct"}
+	req = withGrammar("openai/gpt-4o")
+	if switched, err := s.checkCapabilities(ctx, req, policy); !switched || err != nil || req.Model != "fireworks/llama-v3p3-70b-instruct" {
+		t.Fatalf("expected a reroute to the Fireworks model, got %v, %+v, model %s", switched, err, req.Model)
+	}
+}
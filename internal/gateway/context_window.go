@@ -0,0 +1,329 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+	"modelgate/internal/storage/postgres"
+)
+
+const (
+	defaultReserveOutputTokens = 1024
+	defaultKeepRecentMessages  = 4
+	imageTokenEstimate         = 1000 // Rough cost of an image input
+)
+
+const summaryInstructions = "Summarize the conversation below so the summary can replace it as context for continuing the conversation. " +
+	"Keep facts, decisions, names, numbers, code identifiers and open questions. Reply with the summary only."
+
+// manageContext makes a request fit its model's context window when the
+// role's resilience policy asks for it. It returns true if the model was
+// switched. Requests whose model has no known context limit are left alone.
+func (s *Service) manageContext(ctx context.Context, req *domain.ChatRequest, rolePolicy *domain.RolePolicy) bool {
+	if rolePolicy == nil || !rolePolicy.ResiliencePolicy.Enabled || !rolePolicy.ResiliencePolicy.ContextWindow.Enabled {
+		return false
+	}
+	cw := rolePolicy.ResiliencePolicy.ContextWindow
+
+	limit := s.modelContextLimit(ctx, req.Model)
+	if limit <= 0 {
+		return false
+	}
+	reserve := cw.ReserveOutputTokens
+	if req.MaxTokens != nil && *req.MaxTokens > 0 {
+		reserve = int(*req.MaxTokens)
+	} else if reserve <= 0 {
+		reserve = defaultReserveOutputTokens
+	}
+	estimated := estimateRequestTokens(req)
+	if estimated+reserve <= limit {
+		return false
+	}
+
+	action := &domain.ContextAction{
+		Strategy:        cw.Strategy,
+		ContextLimit:    limit,
+		EstimatedTokens: estimated,
+	}
+	var err error
+	switch cw.Strategy {
+	case domain.ContextStrategyLargerModel:
+		err = s.switchToLargerModel(ctx, req, cw, estimated+reserve, action)
+	case domain.ContextStrategySummarize:
+		err = s.summarizeOldest(ctx, req, cw, limit-reserve, action)
+	default:
+		action.Strategy = domain.ContextStrategyTruncate
+		err = truncateOldest(req, cw, limit-reserve, action)
+	}
+	if err != nil {
+		// Send the request unchanged; the estimate may be pessimistic and the
+		// provider's own error is more useful than a guess
+		slog.Warn("Context window management failed",
+			"strategy", action.Strategy,
+			"model", req.Model,
+			"estimated_tokens", estimated,
+			"context_limit", limit,
+			"error", err,
+			"request_id", req.RequestID)
+		return false
+	}
+
+	req.ContextAction = action
+	slog.Info("Applied context window management",
+		"strategy", action.Strategy,
+		"model", req.Model,
+		"estimated_tokens", estimated,
+		"context_limit", limit,
+		"dropped", action.DroppedMessages,
+		"summarized", action.SummarizedMessages,
+		"request_id", req.RequestID)
+	return action.OriginalModel != ""
+}
+
+// truncateOldest drops the oldest messages until the request fits in budget
+// tokens, keeping the most recent messages and starting on a user message
+func truncateOldest(req *domain.ChatRequest, cw domain.ContextWindowConfig, budget int, action *domain.ContextAction) error {
+	cut := keepFrom(req.Messages, cw.KeepRecentMessages)
+	for start := 1; start <= cut; start++ {
+		// Cutting before a user message never separates tool results from their call
+		if req.Messages[start].Role != "user" {
+			continue
+		}
+		trimmed := *req
+		trimmed.Messages = req.Messages[start:]
+		if estimateRequestTokens(&trimmed) <= budget {
+			action.DroppedMessages = start
+			req.Messages = trimmed.Messages
+			return nil
+		}
+	}
+	return fmt.Errorf("the %d most recent messages alone exceed the context window", len(req.Messages)-cut)
+}
+
+// summarizeOldest replaces the messages before the most recent ones with a
+// summary written by the configured summary model. The summary is added to
+// the system prompt so the remaining messages keep their original order.
+func (s *Service) summarizeOldest(ctx context.Context, req *domain.ChatRequest, cw domain.ContextWindowConfig, budget int, action *domain.ContextAction) error {
+	if cw.SummaryModel == "" {
+		return fmt.Errorf("no summary model configured")
+	}
+	cut := keepFrom(req.Messages, cw.KeepRecentMessages)
+	if cut == 0 {
+		return fmt.Errorf("no messages older than the %d most recent to summarize", len(req.Messages))
+	}
+
+	summaryModel := s.config.Load().ResolveModel(cw.SummaryModel)
+	client, err := s.getClientForTenant(ctx, "", "default", summaryModel)
+	if err != nil {
+		return fmt.Errorf("summary model: %w", err)
+	}
+	summaryReq := &domain.ChatRequest{
+		Model:        summaryModel,
+		SystemPrompt: summaryInstructions,
+		Messages: []domain.Message{{
+			Role:    "user",
+			Content: []domain.ContentBlock{{Type: "text", Text: transcript(req.Messages[:cut])}},
+		}},
+		RequestID: req.RequestID,
+		APIKeyID:  req.APIKeyID,
+		RoleID:    req.RoleID,
+		GroupID:   req.GroupID,
+	}
+	startTime := time.Now()
+	resp, err := client.ChatComplete(ctx, summaryReq)
+	if err != nil {
+		return fmt.Errorf("summarize: %w", err)
+	}
+	if resp.Usage != nil && s.usageRepo != nil {
+		var cost float64
+		if price := s.PriceFor(ctx, summaryModel); price != nil {
+			cost = price.Cost(int64(resp.Usage.PromptTokens), int64(resp.Usage.CompletionTokens))
+		}
+		s.recordUsage(ctx, summaryReq, resp.Content, int64(resp.Usage.PromptTokens), int64(resp.Usage.CompletionTokens), cost, time.Since(startTime), true, "")
+	}
+
+	summary := "Summary of the earlier conversation:\n" + strings.TrimSpace(resp.Content)
+	if req.SystemPrompt != "" {
+		req.SystemPrompt += "\n\n" + summary
+	} else {
+		req.SystemPrompt = summary
+	}
+	req.Messages = req.Messages[cut:]
+	action.SummarizedMessages = cut
+
+	// A long recent tail may still not fit; fall back to dropping from it
+	if estimateRequestTokens(req) > budget {
+		return truncateOldest(req, domain.ContextWindowConfig{KeepRecentMessages: 1}, budget, action)
+	}
+	return nil
+}
+
+// switchToLargerModel moves the request to a model whose context can hold
+// needed tokens: the first configured candidate that fits, or else the
+// smallest such model from the same provider
+func (s *Service) switchToLargerModel(ctx context.Context, req *domain.ChatRequest, cw domain.ContextWindowConfig, needed int, action *domain.ContextAction) error {
+	target := ""
+	for _, candidate := range cw.LargerModels {
+		candidate = s.config.Load().ResolveModel(candidate)
+		if s.modelContextLimit(ctx, candidate) >= needed {
+			target = candidate
+			break
+		}
+	}
+	if target == "" && len(cw.LargerModels) == 0 {
+		target = s.smallestModelWithContext(ctx, req.Model, needed)
+	}
+	if target == "" {
+		return fmt.Errorf("no model with a context window of at least %d tokens", needed)
+	}
+
+	action.OriginalModel = req.Model
+	action.Model = target
+	req.Model = target
+	return nil
+}
+
+// modelContextLimit returns a model's context window in tokens from the
+// config file or the provider's model list, or 0 if it is unknown
+func (s *Service) modelContextLimit(ctx context.Context, model string) int {
+	cfg := s.config.Load()
+	if m, ok := cfg.Models[model]; ok && m.ContextLimit > 0 {
+		return int(m.ContextLimit)
+	}
+	if am := s.availableModel(ctx, model); am != nil {
+		return am.ContextWindow
+	}
+	return 0
+}
+
+// availableModel looks a model up in its provider's model list, or returns
+// nil if it isn't listed
+func (s *Service) availableModel(ctx context.Context, model string) *postgres.AvailableModel {
+	if s.pgStore == nil {
+		return nil
+	}
+	providerType, ok := s.config.Load().GetProviderForModel(model)
+	if !ok {
+		return nil
+	}
+	tenantStore, err := s.pgStore.GetTenantStore("default")
+	if err != nil {
+		return nil
+	}
+	models, err := tenantStore.ListAvailableModels(ctx, string(providerType))
+	if err != nil {
+		return nil
+	}
+	short := config.ExtractModelID(model)
+	for _, am := range models {
+		if am.ModelID == model || am.ModelID == short {
+			return am
+		}
+	}
+	return nil
+}
+
+// smallestModelWithContext finds the available model from the same provider
+// with the smallest context window of at least needed tokens
+func (s *Service) smallestModelWithContext(ctx context.Context, model string, needed int) string {
+	if s.pgStore == nil {
+		return ""
+	}
+	providerType, ok := s.config.Load().GetProviderForModel(model)
+	if !ok {
+		return ""
+	}
+	tenantStore, err := s.pgStore.GetTenantStore("default")
+	if err != nil {
+		return ""
+	}
+	models, err := tenantStore.ListAvailableModels(ctx, string(providerType))
+	if err != nil {
+		return ""
+	}
+	sort.Slice(models, func(i, j int) bool { return models[i].ContextWindow < models[j].ContextWindow })
+	for _, am := range models {
+		if am.IsAvailable && !am.IsDeprecated && am.ContextWindow >= needed {
+			if strings.Contains(am.ModelID, "/") {
+				return am.ModelID
+			}
+			return string(providerType) + "/" + am.ModelID
+		}
+	}
+	return ""
+}
+
+// keepFrom returns the index of the first of the keep most recent messages
+func keepFrom(msgs []domain.Message, keep int) int {
+	if keep <= 0 {
+		keep = defaultKeepRecentMessages
+	}
+	if keep > len(msgs) {
+		return 0
+	}
+	return len(msgs) - keep
+}
+
+// estimateRequestTokens roughly estimates the prompt size of a request
+// (about 4 characters per token, plus a flat cost per image)
+func estimateRequestTokens(req *domain.ChatRequest) int {
+	chars := len(req.SystemPrompt)
+	images := 0
+	for _, msg := range req.Messages {
+		for _, block := range msg.Content {
+			switch {
+			case block.Type == "image":
+				images++
+			case block.ToolResult != nil:
+				for _, r := range block.ToolResult.Result {
+					chars += len(r.Text)
+				}
+			default:
+				chars += len(block.Text)
+			}
+		}
+		for _, tc := range msg.ToolCalls {
+			args, _ := json.Marshal(tc.Function.Arguments)
+			chars += len(tc.Function.Name) + len(args)
+		}
+	}
+	if len(req.Tools) > 0 {
+		tools, _ := json.Marshal(req.Tools)
+		chars += len(tools)
+	}
+	return chars/4 + images*imageTokenEstimate
+}
+
+// transcript renders messages as plain text for summarization
+func transcript(msgs []domain.Message) string {
+	var sb strings.Builder
+	for _, msg := range msgs {
+		sb.WriteString(msg.Role)
+		sb.WriteString(": ")
+		for _, block := range msg.Content {
+			if block.Text != "" {
+				sb.WriteString(block.Text)
+				sb.WriteString(" ")
+			}
+			if block.ToolResult != nil {
+				for _, r := range block.ToolResult.Result {
+					sb.WriteString(r.Text)
+					sb.WriteString(" ")
+				}
+			}
+		}
+		for _, tc := range msg.ToolCalls {
+			args, _ := json.Marshal(tc.Function.Arguments)
+			fmt.Fprintf(&sb, "[called %s %s] ", tc.Function.Name, args)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
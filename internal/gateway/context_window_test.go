@@ -0,0 +1,37 @@
+package gateway
+
+import (
+	"strings"
+	"testing"
+
+	"modelgate/internal/domain"
+)
+
+func TestTruncateOldestKeepsToolResultsWithTheirCall(t *testing.T) {
+	long := strings.Repeat("x", 4000) // ~1000 tokens
+	msg := func(role, text string) domain.Message {
+		return domain.Message{Role: role, Content: []domain.ContentBlock{{Type: "text", Text: text}}}
+	}
+	req := &domain.ChatRequest{Messages: []domain.Message{
+		msg("user", long),
+		{Role: "assistant", ToolCalls: []domain.ToolCall{{ID: "1", Function: domain.FunctionCall{Name: "search"}}}},
+		msg("tool", long),
+		msg("user", "short follow-up"),
+		msg("assistant", "ok"),
+		msg("user", "last question"),
+	}}
+
+	action := &domain.ContextAction{}
+	if err := truncateOldest(req, domain.ContextWindowConfig{KeepRecentMessages: 3}, 500, action); err != nil {
+		t.Fatalf("truncateOldest failed: %v", err)
+	}
+	// The cut can only fall before a user message, so the tool call and its result go together
+	if action.DroppedMessages != 3 || len(req.Messages) != 3 || req.Messages[0].Role != "user" {
+		t.Fatalf("expected to drop 3 messages and start on a user message, got %d dropped: %+v", action.DroppedMessages, req.Messages)
+	}
+
+	req.Messages = []domain.Message{msg("user", long), msg("user", long)}
+	if err := truncateOldest(req, domain.ContextWindowConfig{KeepRecentMessages: 1}, 500, &domain.ContextAction{}); err == nil {
+		t.Fatal("expected an error when the most recent message alone is too large")
+	}
+}
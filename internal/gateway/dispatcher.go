@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"log/slog"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -18,6 +19,14 @@ var (
 	ErrQueueTimeout  = errors.New("request timed out waiting in queue")
 	ErrShuttingDown  = errors.New("server is shutting down")
 	ErrTenantLimited = errors.New("tenant concurrency limit reached")
+	ErrKeyLimited    = errors.New("API key concurrency limit reached")
+	ErrRoleLimited   = errors.New("role concurrency limit reached")
+)
+
+// Concurrency limit scopes, used in stats and metric labels
+const (
+	ConcurrencyScopeAPIKey = "api_key"
+	ConcurrencyScopeRole   = "role"
 )
 
 // =============================================================================
@@ -34,10 +43,32 @@ type DispatchRequest struct {
 	RoleID     string
 	GroupID    string
 	Priority   int // Higher = processed first (0-10)
+	Weight     int // Share of the priority band relative to other roles or API keys (0 = 1)
+
+	// Per-request concurrency limits from the role policy (0 = dispatcher default)
+	MaxConcurrentPerKey  int32
+	MaxConcurrentPerRole int32
+	QueueWhenLimited     bool // Wait for a free slot instead of failing with ErrKeyLimited/ErrRoleLimited
+
+	// Per-role limits of every role the API key holds, directly and through
+	// its group (0 = dispatcher default). When nil, RoleID is limited by
+	// MaxConcurrentPerRole.
+	RoleLimits map[string]int32
+
+	// With the durable queue, a retry with the same key (per API key) gets the
+	// first request's outcome instead of running again
+	IdempotencyKey string
+
+	// OnQueued, if set, is called with the request's place in the queue while
+	// it waits for a worker, from the goroutine that called Submit
+	OnQueued func(QueueStatus)
 
 	// Internal
 	ResponseCh chan *DispatchResult
 	EnqueuedAt time.Time
+	release    func() // Releases the API key and role slots once processing is done
+	durableID  string // Durable queue entry, when the request is persisted
+	recovered  bool   // Picked up from the durable queue after a restart
 }
 
 // DispatchResult contains the result of processing a request
@@ -45,6 +76,7 @@ type DispatchResult struct {
 	Response *domain.ChatResponse      // For non-streaming
 	EventsCh <-chan domain.StreamEvent // For streaming
 	Error    error
+	Replayed bool // Stored response of an earlier request with the same idempotency key
 }
 
 // =============================================================================
@@ -72,6 +104,15 @@ type DispatcherConfig struct {
 	// Queue distribution (percentages for priority queues)
 	HighPriorityPercent   int // e.g., 30% of queue for high priority
 	NormalPriorityPercent int // e.g., 50% of queue for normal priority
+
+	// Default in-flight limits (0 = unlimited)
+	MaxConcurrentPerKey     int32
+	MaxConcurrentPerRole    int32
+	QueueOnConcurrencyLimit bool // Wait up to QueueTimeout for a free slot instead of rejecting
+
+	// Fair queuing within a priority band
+	FairnessKey       string        // FairnessKeyRole or FairnessKeyAPIKey
+	StarvationTimeout time.Duration // Requests queued longer are served next regardless of band (0 = off)
 }
 
 // DefaultDispatcherConfig returns sensible defaults for adaptive scaling
@@ -89,6 +130,8 @@ func DefaultDispatcherConfig() DispatcherConfig {
 		ScaleInterval:         5 * time.Second,
 		HighPriorityPercent:   30,
 		NormalPriorityPercent: 50,
+		FairnessKey:           FairnessKeyRole,
+		StarvationTimeout:     10 * time.Second,
 	}
 }
 
@@ -99,11 +142,17 @@ func DefaultDispatcherConfig() DispatcherConfig {
 // DispatcherMetrics tracks dispatcher performance
 type DispatcherMetrics struct {
 	// Request counts
-	RequestsReceived  int64
-	RequestsQueued    int64
-	RequestsProcessed int64
-	RequestsRejected  int64
-	RequestsTimedOut  int64
+	RequestsReceived    int64
+	RequestsQueued      int64
+	RequestsProcessed   int64
+	RequestsRejected    int64
+	RequestsTimedOut    int64
+	RequestsKeyLimited  int64
+	RequestsRoleLimited int64
+
+	// Durable queue
+	RequestsDeduplicated int64 // Answered from an earlier request with the same idempotency key
+	RequestsRecovered    int64 // Picked up after a restart
 
 	// Queue depths (current)
 	HighPriorityQueueDepth   int32
@@ -131,13 +180,21 @@ type DispatcherMetrics struct {
 
 // TenantLimiter tracks per-tenant concurrency limits
 type TenantLimiter struct {
-	mu       sync.RWMutex
-	limiters map[string]*tenantSemaphore
+	mu        sync.RWMutex
+	limiters  map[string]*tenantSemaphore
+	evictIdle bool // Drop entries with nothing in flight
 }
 
 type tenantSemaphore struct {
-	current int32
-	limit   int32
+	current  int32
+	limit    int32
+	released chan struct{} // Closed and replaced on every release to wake waiters
+}
+
+// LimiterStats is a snapshot of one limiter entry
+type LimiterStats struct {
+	Current int32 `json:"current"`
+	Limit   int32 `json:"limit"` // 0 = unlimited
 }
 
 // NewTenantLimiter creates a new tenant limiter
@@ -147,6 +204,16 @@ func NewTenantLimiter() *TenantLimiter {
 	}
 }
 
+// newCallerLimiter creates a limiter for API keys or roles. Their limit is
+// given on every acquire, so an entry is dropped once nothing is in flight
+// and the limiter only holds callers with requests running.
+func newCallerLimiter() *TenantLimiter {
+	return &TenantLimiter{
+		limiters:  make(map[string]*tenantSemaphore),
+		evictIdle: true,
+	}
+}
+
 // SetLimit sets or updates the limit for a tenant
 func (tl *TenantLimiter) SetLimit(tenantID string, limit int32) {
 	tl.mu.Lock()
@@ -189,6 +256,59 @@ func (tl *TenantLimiter) Release(tenantID string) {
 		if sem.current > 0 {
 			sem.current--
 		}
+		if sem.released != nil {
+			close(sem.released)
+			sem.released = nil
+		}
+		if tl.evictIdle && sem.current == 0 {
+			delete(tl.limiters, tenantID)
+		}
+	}
+}
+
+// AcquireWait acquires a slot, waiting up to timeout for one to be released.
+// The given limit replaces the stored one; a limit <= 0 only counts the request.
+// It returns the number of in-flight requests for id and whether a slot was acquired.
+func (tl *TenantLimiter) AcquireWait(ctx context.Context, id string, limit int32, timeout time.Duration) (int32, bool) {
+	var deadline <-chan time.Time
+	for {
+		tl.mu.Lock()
+		sem, exists := tl.limiters[id]
+		if !exists {
+			sem = &tenantSemaphore{}
+			tl.limiters[id] = sem
+		}
+		sem.limit = limit
+		if limit <= 0 || sem.current < limit {
+			sem.current++
+			current := sem.current
+			tl.mu.Unlock()
+			return current, true
+		}
+		if timeout <= 0 {
+			current := sem.current
+			tl.mu.Unlock()
+			return current, false
+		}
+		if sem.released == nil {
+			sem.released = make(chan struct{})
+		}
+		released := sem.released
+		current := sem.current
+		tl.mu.Unlock()
+
+		if deadline == nil {
+			timer := time.NewTimer(timeout)
+			defer timer.Stop()
+			deadline = timer.C
+		}
+		select {
+		case <-released:
+		case <-ctx.Done():
+			return current, false
+		case <-deadline:
+			return current, false
+		}
 	}
 }
 
@@ -203,6 +323,18 @@ func (tl *TenantLimiter) GetStats(tenantID string) (current, limit int32) {
 	return 0, 0
 }
 
+// Snapshot returns the stats of every tracked ID
+func (tl *TenantLimiter) Snapshot() map[string]LimiterStats {
+	tl.mu.RLock()
+	defer tl.mu.RUnlock()
+
+	stats := make(map[string]LimiterStats, len(tl.limiters))
+	for id, sem := range tl.limiters {
+		stats[id] = LimiterStats{Current: sem.current, Limit: sem.limit}
+	}
+	return stats
+}
+
 // =============================================================================
 // Dispatcher Implementation
 // =============================================================================
@@ -211,13 +343,11 @@ func (tl *TenantLimiter) GetStats(tenantID string) (current, limit int32) {
 type Dispatcher struct {
 	mu sync.RWMutex
 
-	// Configuration
-	config DispatcherConfig
+	// Configuration (swapped by Reconfigure)
+	config atomic.Pointer[DispatcherConfig]
 
-	// Priority-based request queues
-	highPriorityQueue   chan *DispatchRequest
-	normalPriorityQueue chan *DispatchRequest
-	lowPriorityQueue    chan *DispatchRequest
+	// Priority bands with fair queuing across roles or API keys
+	queue *fairQueue
 
 	// Adaptive worker pool
 	activeWorkers atomic.Int32
@@ -229,12 +359,18 @@ type Dispatcher struct {
 	// Gateway service for actual processing
 	gateway *Service
 
-	// Per-tenant limiting
+	// Per-tenant, per-API-key and per-role limiting
 	tenantLimiter *TenantLimiter
+	keyLimiter    *TenantLimiter
+	roleLimiter   *TenantLimiter
 
 	// Scaling control
 	scalerStop chan struct{}
 
+	// Durable queue (nil = in-memory only)
+	queueStore     QueueStore
+	queueRetention time.Duration
+
 	// Metrics
 	metrics DispatcherMetrics
 }
@@ -257,17 +393,17 @@ func NewDispatcher(cfg DispatcherConfig, gateway *Service) *Dispatcher {
 	}
 
 	d := &Dispatcher{
-		config:              cfg,
-		highPriorityQueue:   make(chan *DispatchRequest, highQueueSize),
-		normalPriorityQueue: make(chan *DispatchRequest, normalQueueSize),
-		lowPriorityQueue:    make(chan *DispatchRequest, lowQueueSize),
-		workAvailable:       make(chan struct{}, 1),
-		shutdownCh:          make(chan struct{}),
-		scalerStop:          make(chan struct{}),
-		gateway:             gateway,
-		tenantLimiter:       NewTenantLimiter(),
-		metrics:             DispatcherMetrics{},
+		queue:         newFairQueue([numBands]int{highQueueSize, normalQueueSize, lowQueueSize}),
+		workAvailable: make(chan struct{}, 1),
+		shutdownCh:    make(chan struct{}),
+		scalerStop:    make(chan struct{}),
+		gateway:       gateway,
+		tenantLimiter: NewTenantLimiter(),
+		keyLimiter:    newCallerLimiter(),
+		roleLimiter:   newCallerLimiter(),
+		metrics:       DispatcherMetrics{},
 	}
+	d.config.Store(&cfg)
 
 	slog.Info("Adaptive dispatcher created",
 		"min_workers", cfg.MinWorkers,
@@ -278,6 +414,7 @@ func NewDispatcher(cfg DispatcherConfig, gateway *Service) *Dispatcher {
 		"low_queue_size", lowQueueSize,
 		"scale_up_threshold", cfg.ScaleUpThreshold,
 		"scale_down_threshold", cfg.ScaleDownThreshold,
+		"fairness_key", cfg.FairnessKey,
 	)
 
 	return d
@@ -295,14 +432,51 @@ func (d *Dispatcher) Start() {
 	d.isRunning = true
 
 	// Start minimum workers
-	for i := 0; i < d.config.MinWorkers; i++ {
+	for i := 0; i < d.config.Load().MinWorkers; i++ {
 		d.spawnWorker()
 	}
 
 	// Start auto-scaler
 	go d.autoScaler()
 
-	slog.Info("Adaptive dispatcher started", "initial_workers", d.config.MinWorkers)
+	if d.queueStore != nil {
+		go d.recoverDurable()
+		go d.cleanupDurable()
+	}
+
+	slog.Info("Adaptive dispatcher started", "initial_workers", d.config.Load().MinWorkers)
+}
+
+// Reconfigure applies new worker pool sizing, scaling thresholds and default
+// concurrency limits without interrupting queued or running requests. Queue
+// capacity is fixed at creation, so the queue settings of cfg are ignored.
+func (d *Dispatcher) Reconfigure(cfg DispatcherConfig) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	current := d.config.Load()
+	cfg.MaxQueuedRequests = current.MaxQueuedRequests
+	cfg.HighPriorityPercent = current.HighPriorityPercent
+	cfg.NormalPriorityPercent = current.NormalPriorityPercent
+	d.config.Store(&cfg)
+
+	// Extra workers above a lowered minimum retire through the idle timeout
+	if d.isRunning {
+		for i := int(d.activeWorkers.Load()); i < cfg.MinWorkers; i++ {
+			d.spawnWorker()
+		}
+	}
+
+	slog.Info("Dispatcher reconfigured",
+		"min_workers", cfg.MinWorkers,
+		"max_workers", cfg.MaxWorkers,
+		"scale_up_threshold", cfg.ScaleUpThreshold,
+		"scale_down_threshold", cfg.ScaleDownThreshold,
+		"max_concurrent_per_key", cfg.MaxConcurrentPerKey,
+		"max_concurrent_per_role", cfg.MaxConcurrentPerRole,
+		"fairness_key", cfg.FairnessKey,
+		"starvation_timeout", cfg.StarvationTimeout,
+	)
 }
 
 // spawnWorker creates a new adaptive worker goroutine
@@ -333,33 +507,34 @@ func (d *Dispatcher) Stop() {
 func (d *Dispatcher) Submit(ctx context.Context, req *DispatchRequest) (*DispatchResult, error) {
 	atomic.AddInt64(&d.metrics.RequestsReceived, 1)
 
-	req.EnqueuedAt = time.Now()
-	req.ResponseCh = make(chan *DispatchResult, 1)
-
-	// Select appropriate queue based on priority
-	queue := d.selectQueue(req.Priority)
-
-	// Try to enqueue without blocking
-	select {
-	case queue <- req:
-		// Successfully queued
-		atomic.AddInt64(&d.metrics.RequestsQueued, 1)
-		d.updateQueueDepth(req.Priority, 1)
+	// Reserve API key and role slots before queuing; they are held until processing is done
+	if err := d.acquireCallerSlots(ctx, req); err != nil {
+		return nil, err
+	}
 
-		// Signal that work is available
-		select {
-		case d.workAvailable <- struct{}{}:
-		default:
+	if d.queueStore != nil && !req.ChatReq.Streaming {
+		result, err := d.enqueueDurable(ctx, req)
+		if result != nil || err != nil {
+			req.release()
+			return result, err
 		}
+	}
 
-		return d.waitForResult(ctx, req)
+	req.EnqueuedAt = time.Now()
+	req.ResponseCh = make(chan *DispatchResult, 1)
 
-	case <-ctx.Done():
+	if err := ctx.Err(); err != nil {
+		req.release()
+		d.completeDurable(req, nil, err)
 		atomic.AddInt64(&d.metrics.RequestsTimedOut, 1)
-		return nil, ctx.Err()
+		return nil, err
+	}
 
-	default:
+	// Try to enqueue without blocking
+	if !d.enqueue(req) {
 		// Queue is full - apply backpressure
+		req.release()
+		d.completeDurable(req, nil, ErrQueueFull)
 		atomic.AddInt64(&d.metrics.RequestsRejected, 1)
 
 		slog.Warn("Request rejected - queue full",
@@ -370,44 +545,200 @@ func (d *Dispatcher) Submit(ctx context.Context, req *DispatchRequest) (*Dispatc
 
 		return nil, ErrQueueFull
 	}
+	atomic.AddInt64(&d.metrics.RequestsQueued, 1)
+	return d.waitForResult(ctx, req)
+}
+
+// enqueue adds a request to its priority band and wakes a worker. It returns
+// false when the band is full.
+func (d *Dispatcher) enqueue(req *DispatchRequest) bool {
+	if !d.queue.push(req, d.flowKey(req), req.Weight) {
+		return false
+	}
+	d.updateQueueDepth(req.Priority, 1)
+	d.signalWork()
+	return true
+}
+
+// flowKey returns the fair queuing flow a request is charged to
+func (d *Dispatcher) flowKey(req *DispatchRequest) string {
+	if d.config.Load().FairnessKey != FairnessKeyAPIKey && req.RoleID != "" {
+		return "role:" + req.RoleID
+	}
+	if req.APIKeyID != "" {
+		return "api_key:" + req.APIKeyID
+	}
+	return "anonymous"
 }
 
-// selectQueue returns the appropriate queue based on priority (0-10)
-func (d *Dispatcher) selectQueue(priority int) chan *DispatchRequest {
-	switch {
-	case priority >= 8:
-		return d.highPriorityQueue
-	case priority >= 4:
-		return d.normalPriorityQueue
+// signalWork wakes one idle worker
+func (d *Dispatcher) signalWork() {
+	select {
+	case d.workAvailable <- struct{}{}:
 	default:
-		return d.lowPriorityQueue
 	}
 }
 
+// acquireCallerSlots acquires the API key and role slots for a request and sets
+// req.release. Depending on the policy it rejects or waits when a limit is reached.
+func (d *Dispatcher) acquireCallerSlots(ctx context.Context, req *DispatchRequest) error {
+	keyLimit := req.MaxConcurrentPerKey
+	if keyLimit <= 0 {
+		keyLimit = d.config.Load().MaxConcurrentPerKey
+	}
+	roleLimits := req.RoleLimits
+	if roleLimits == nil && req.RoleID != "" {
+		roleLimits = map[string]int32{req.RoleID: req.MaxConcurrentPerRole}
+	}
+	// Roles are acquired in a fixed order so requests sharing roles can't
+	// each hold a slot the other is waiting for
+	roleIDs := make([]string, 0, len(roleLimits))
+	for roleID := range roleLimits {
+		roleIDs = append(roleIDs, roleID)
+	}
+	sort.Strings(roleIDs)
+
+	var timeout time.Duration
+	if req.QueueWhenLimited || d.config.Load().QueueOnConcurrencyLimit {
+		timeout = d.config.Load().QueueTimeout
+	}
+
+	keyAcquired := false
+	var rolesAcquired []string
+	var once sync.Once
+	req.release = func() {
+		once.Do(func() {
+			if keyAcquired {
+				d.keyLimiter.Release(req.APIKeyID)
+				d.addInFlight(ConcurrencyScopeAPIKey, req.RoleID, -1)
+			}
+			for _, roleID := range rolesAcquired {
+				d.roleLimiter.Release(roleID)
+				d.addInFlight(ConcurrencyScopeRole, roleID, -1)
+			}
+		})
+	}
+
+	if req.APIKeyID != "" {
+		current, ok := d.keyLimiter.AcquireWait(ctx, req.APIKeyID, keyLimit, timeout)
+		if !ok {
+			d.recordConcurrencyLimited(ConcurrencyScopeAPIKey, req.APIKeyID, req.RoleID, current, keyLimit)
+			atomic.AddInt64(&d.metrics.RequestsKeyLimited, 1)
+			return ErrKeyLimited
+		}
+		keyAcquired = true
+		d.addInFlight(ConcurrencyScopeAPIKey, req.RoleID, 1)
+	}
+
+	for _, roleID := range roleIDs {
+		roleLimit := roleLimits[roleID]
+		if roleLimit <= 0 {
+			roleLimit = d.config.Load().MaxConcurrentPerRole
+		}
+		current, ok := d.roleLimiter.AcquireWait(ctx, roleID, roleLimit, timeout)
+		if !ok {
+			req.release()
+			d.recordConcurrencyLimited(ConcurrencyScopeRole, roleID, roleID, current, roleLimit)
+			atomic.AddInt64(&d.metrics.RequestsRoleLimited, 1)
+			return ErrRoleLimited
+		}
+		rolesAcquired = append(rolesAcquired, roleID)
+		d.addInFlight(ConcurrencyScopeRole, roleID, 1)
+	}
+
+	return nil
+}
+
+// addInFlight adjusts the in-flight gauge of a scope. API key requests are
+// counted per role of the key, so the metric grows with roles, not keys.
+func (d *Dispatcher) addInFlight(scope, roleID string, delta int) {
+	if d.gateway != nil && d.gateway.metrics != nil {
+		d.gateway.metrics.AddDispatcherInFlight(scope, roleID, delta)
+	}
+}
+
+// recordConcurrencyLimited logs and counts a request rejected by a concurrency limit
+func (d *Dispatcher) recordConcurrencyLimited(scope, id, roleID string, current, limit int32) {
+	slog.Warn("Concurrency limit reached",
+		"scope", scope,
+		"id", id,
+		"in_flight", current,
+		"limit", limit,
+	)
+	if d.gateway != nil && d.gateway.metrics != nil {
+		d.gateway.metrics.RecordDispatcherConcurrencyLimit(scope, roleID)
+	}
+}
+
+// priorityBand names the band a priority is queued in
+func priorityBand(priority int) string {
+	switch bandFor(priority) {
+	case bandHigh:
+		return "high"
+	case bandNormal:
+		return "normal"
+	default:
+		return "low"
+	}
+}
+
+// recordQueueWait exports a request's queue wait to Prometheus
+func (d *Dispatcher) recordQueueWait(req *DispatchRequest, wait time.Duration) {
+	if d.gateway == nil || d.gateway.metrics == nil || req.ChatReq == nil {
+		return
+	}
+	provider, _ := d.gateway.config.Load().GetProviderForModel(req.ChatReq.Model)
+	d.gateway.metrics.RecordDispatcherQueueWait(priorityBand(req.Priority), req.ChatReq.Model, string(provider), req.RoleID, wait)
+}
+
 // updateQueueDepth updates queue depth metrics
 func (d *Dispatcher) updateQueueDepth(priority int, delta int32) {
-	switch {
-	case priority >= 8:
+	switch bandFor(priority) {
+	case bandHigh:
 		atomic.AddInt32(&d.metrics.HighPriorityQueueDepth, delta)
-	case priority >= 4:
+	case bandNormal:
 		atomic.AddInt32(&d.metrics.NormalPriorityQueueDepth, delta)
 	default:
 		atomic.AddInt32(&d.metrics.LowPriorityQueueDepth, delta)
 	}
 }
 
-// waitForResult waits for the request to be processed
+// waitForResult waits for the request to be processed, reporting its queue
+// status to req.OnQueued until a worker picks it up
 func (d *Dispatcher) waitForResult(ctx context.Context, req *DispatchRequest) (*DispatchResult, error) {
-	select {
-	case result := <-req.ResponseCh:
-		return result, nil
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	case <-d.shutdownCh:
-		return nil, ErrShuttingDown
-	case <-time.After(d.config.QueueTimeout):
-		atomic.AddInt64(&d.metrics.RequestsTimedOut, 1)
-		return nil, ErrQueueTimeout
+	timeout := time.NewTimer(d.config.Load().QueueTimeout)
+	defer timeout.Stop()
+
+	// The first status is sent once the request has waited queueStatusDelay,
+	// so requests picked up right away get none
+	var progress *time.Timer
+	var progressC <-chan time.Time
+	if req.OnQueued != nil {
+		progress = time.NewTimer(queueStatusDelay)
+		defer progress.Stop()
+		progressC = progress.C
+	}
+
+	for {
+		select {
+		case result := <-req.ResponseCh:
+			return result, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-d.shutdownCh:
+			return nil, ErrShuttingDown
+		case <-timeout.C:
+			atomic.AddInt64(&d.metrics.RequestsTimedOut, 1)
+			return nil, ErrQueueTimeout
+		case <-progressC:
+			status, queued := d.QueueStatus(req)
+			if !queued {
+				progressC = nil
+				continue
+			}
+			req.OnQueued(status)
+			progress.Reset(queueStatusInterval)
+		}
 	}
 }
 
@@ -418,7 +749,7 @@ func (d *Dispatcher) worker() {
 		d.workerWg.Done()
 	}()
 
-	idleTimer := time.NewTimer(d.config.IdleTimeout)
+	idleTimer := time.NewTimer(d.config.Load().IdleTimeout)
 	defer idleTimer.Stop()
 
 	for {
@@ -429,63 +760,42 @@ func (d *Dispatcher) worker() {
 			default:
 			}
 		}
-		idleTimer.Reset(d.config.IdleTimeout)
+		idleTimer.Reset(d.config.Load().IdleTimeout)
 
-		// Priority-based selection: high > normal > low
 		select {
 		case <-d.shutdownCh:
 			return
-
-		case req := <-d.highPriorityQueue:
-			d.updateQueueDepth(10, -1)
-			d.processRequest(req)
-
 		default:
-			select {
-			case <-d.shutdownCh:
-				return
-
-			case req := <-d.highPriorityQueue:
-				d.updateQueueDepth(10, -1)
-				d.processRequest(req)
+		}
 
-			case req := <-d.normalPriorityQueue:
-				d.updateQueueDepth(5, -1)
-				d.processRequest(req)
+		if req := d.queue.pop(time.Now(), d.config.Load().StarvationTimeout); req != nil {
+			d.updateQueueDepth(req.Priority, -1)
+			// Pass the wake-up on so other idle workers pick up the rest
+			if d.queue.len() > 0 {
+				d.signalWork()
+			}
+			d.processRequest(req)
+			continue
+		}
 
-			default:
-				select {
-				case <-d.shutdownCh:
-					return
-
-				case req := <-d.highPriorityQueue:
-					d.updateQueueDepth(10, -1)
-					d.processRequest(req)
-
-				case req := <-d.normalPriorityQueue:
-					d.updateQueueDepth(5, -1)
-					d.processRequest(req)
-
-				case req := <-d.lowPriorityQueue:
-					d.updateQueueDepth(0, -1)
-					d.processRequest(req)
-
-				case <-idleTimer.C:
-					// Check if we should exit (above minimum workers)
-					current := d.activeWorkers.Load()
-					if int(current) > d.config.MinWorkers {
-						slog.Debug("Worker exiting due to idle timeout",
-							"current_workers", current,
-							"min_workers", d.config.MinWorkers)
-						atomic.AddInt64(&d.metrics.WorkersScaledDown, 1)
-						return
-					}
-					// We're at minimum, continue waiting
+		select {
+		case <-d.shutdownCh:
+			return
 
-				case <-d.workAvailable:
-					// Work signal received, loop back to try getting work
-				}
+		case <-idleTimer.C:
+			// Check if we should exit (above minimum workers)
+			current := d.activeWorkers.Load()
+			if int(current) > d.config.Load().MinWorkers {
+				slog.Debug("Worker exiting due to idle timeout",
+					"current_workers", current,
+					"min_workers", d.config.Load().MinWorkers)
+				atomic.AddInt64(&d.metrics.WorkersScaledDown, 1)
+				return
 			}
+			// We're at minimum, continue waiting
+
+		case <-d.workAvailable:
+			// Work signal received, loop back to try getting work
 		}
 	}
 }
@@ -497,6 +807,7 @@ func (d *Dispatcher) processRequest(req *DispatchRequest) {
 	waitMs := waitTime.Milliseconds()
 	atomic.AddInt64(&d.metrics.TotalQueueWaitMs, waitMs)
 	atomic.StoreInt64(&d.metrics.LastQueueWaitMs, waitMs)
+	d.recordQueueWait(req, waitTime)
 
 	// Update max wait time
 	for {
@@ -506,12 +817,26 @@ func (d *Dispatcher) processRequest(req *DispatchRequest) {
 		}
 	}
 
+	// Caller slots are released when processing is done; streams hold them until the last event
+	streaming := false
+	defer func() {
+		if !streaming && req.release != nil {
+			req.release()
+		}
+	}()
+
 	// Check if context already cancelled
 	if req.Ctx.Err() != nil {
+		d.completeDurable(req, nil, req.Ctx.Err())
 		req.ResponseCh <- &DispatchResult{Error: req.Ctx.Err()}
 		return
 	}
 
+	// A persisted request is only sent to the provider by the worker that claims it
+	if !d.claimDurable(req) {
+		return
+	}
+
 	// Get tenant limit based on plan
 	tenantLimit := d.getTenantLimit(req.TenantSlug)
 
@@ -520,6 +845,7 @@ func (d *Dispatcher) processRequest(req *DispatchRequest) {
 		slog.Warn("Tenant concurrency limit reached",
 			"tenant", req.TenantSlug,
 			"limit", tenantLimit)
+		d.completeDurable(req, nil, ErrTenantLimited)
 		req.ResponseCh <- &DispatchResult{Error: ErrTenantLimited}
 		return
 	}
@@ -531,10 +857,15 @@ func (d *Dispatcher) processRequest(req *DispatchRequest) {
 	var result DispatchResult
 	if req.ChatReq.Streaming {
 		events, err := d.gateway.ChatStream(req.Ctx, req.ChatReq)
+		if err == nil && events != nil && req.release != nil {
+			streaming = true
+			events = releaseOnClose(req.Ctx, events, req.release)
+		}
 		result = DispatchResult{EventsCh: events, Error: err}
 	} else {
 		resp, err := d.gateway.ChatComplete(req.Ctx, req.ChatReq)
 		result = DispatchResult{Response: resp, Error: err}
+		d.completeDurable(req, resp, err)
 	}
 
 	// Record processing time
@@ -561,6 +892,29 @@ func (d *Dispatcher) processRequest(req *DispatchRequest) {
 	}
 }
 
+// releaseOnClose forwards stream events and calls release once the stream ends
+// or the caller goes away
+func releaseOnClose(ctx context.Context, events <-chan domain.StreamEvent, release func()) <-chan domain.StreamEvent {
+	out := make(chan domain.StreamEvent)
+	go func() {
+		defer close(out)
+		defer release()
+		for event := range events {
+			select {
+			case out <- event:
+			case <-ctx.Done():
+				// Keep draining so the producer is not blocked on a departed caller
+				go func() {
+					for range events {
+					}
+				}()
+				return
+			}
+		}
+	}()
+	return out
+}
+
 // getTenantLimit returns the concurrent request limit for a tenant based on plan
 func (d *Dispatcher) getTenantLimit(tenantSlug string) int32 {
 	// TODO: Look up from database based on tenant plan
@@ -584,9 +938,20 @@ func (d *Dispatcher) TenantStats(tenantID string) (current, limit int32) {
 	return d.tenantLimiter.GetStats(tenantID)
 }
 
+// APIKeyStats returns in-flight requests and limits per API key
+func (d *Dispatcher) APIKeyStats() map[string]LimiterStats {
+	return d.keyLimiter.Snapshot()
+}
+
+// RoleStats returns in-flight requests and limits per role
+func (d *Dispatcher) RoleStats() map[string]LimiterStats {
+	return d.roleLimiter.Snapshot()
+}
+
 // autoScaler monitors load and adjusts worker count
 func (d *Dispatcher) autoScaler() {
-	ticker := time.NewTicker(d.config.ScaleInterval)
+	interval := d.config.Load().ScaleInterval
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	for {
@@ -595,24 +960,30 @@ func (d *Dispatcher) autoScaler() {
 			return
 		case <-ticker.C:
 			d.checkAndScale()
+			if next := d.config.Load().ScaleInterval; next != interval {
+				interval = next
+				ticker.Reset(interval)
+			}
 		}
 	}
 }
 
 // checkAndScale adjusts worker count based on queue utilization
 func (d *Dispatcher) checkAndScale() {
+	cfg := d.config.Load()
+
 	// Calculate queue utilization
-	queued := len(d.highPriorityQueue) + len(d.normalPriorityQueue) + len(d.lowPriorityQueue)
-	maxQueued := d.config.MaxQueuedRequests
+	queued := d.queue.len()
+	maxQueued := cfg.MaxQueuedRequests
 	utilization := float64(queued) / float64(maxQueued)
 
 	currentWorkers := int(d.activeWorkers.Load())
 
-	if utilization > d.config.ScaleUpThreshold && currentWorkers < d.config.MaxWorkers {
+	if utilization > cfg.ScaleUpThreshold && currentWorkers < cfg.MaxWorkers {
 		// Scale up
-		toAdd := d.config.ScaleUpStep
-		if currentWorkers+toAdd > d.config.MaxWorkers {
-			toAdd = d.config.MaxWorkers - currentWorkers
+		toAdd := cfg.ScaleUpStep
+		if currentWorkers+toAdd > cfg.MaxWorkers {
+			toAdd = cfg.MaxWorkers - currentWorkers
 		}
 
 		if toAdd > 0 {
@@ -628,12 +999,12 @@ func (d *Dispatcher) checkAndScale() {
 			atomic.AddInt64(&d.metrics.WorkersScaledUp, int64(toAdd))
 		}
 
-	} else if utilization < d.config.ScaleDownThreshold && currentWorkers > d.config.MinWorkers {
+	} else if utilization < cfg.ScaleDownThreshold && currentWorkers > cfg.MinWorkers {
 		// Scale down is handled by idle timeout in workers
 		// Just log for observability
 		slog.Debug("Low utilization, workers will scale down via idle timeout",
 			"current", currentWorkers,
-			"min", d.config.MinWorkers,
+			"min", cfg.MinWorkers,
 			"utilization", utilization,
 		)
 	}
@@ -651,6 +1022,10 @@ func (d *Dispatcher) Stats() DispatcherMetrics {
 		RequestsProcessed:        atomic.LoadInt64(&d.metrics.RequestsProcessed),
 		RequestsRejected:         atomic.LoadInt64(&d.metrics.RequestsRejected),
 		RequestsTimedOut:         atomic.LoadInt64(&d.metrics.RequestsTimedOut),
+		RequestsKeyLimited:       atomic.LoadInt64(&d.metrics.RequestsKeyLimited),
+		RequestsRoleLimited:      atomic.LoadInt64(&d.metrics.RequestsRoleLimited),
+		RequestsDeduplicated:     atomic.LoadInt64(&d.metrics.RequestsDeduplicated),
+		RequestsRecovered:        atomic.LoadInt64(&d.metrics.RequestsRecovered),
 		HighPriorityQueueDepth:   atomic.LoadInt32(&d.metrics.HighPriorityQueueDepth),
 		NormalPriorityQueueDepth: atomic.LoadInt32(&d.metrics.NormalPriorityQueueDepth),
 		LowPriorityQueueDepth:    atomic.LoadInt32(&d.metrics.LowPriorityQueueDepth),
@@ -667,40 +1042,43 @@ func (d *Dispatcher) Stats() DispatcherMetrics {
 	}
 }
 
-// IsHealthy returns true if dispatcher is operating normally
-func (d *Dispatcher) IsHealthy() bool {
+// Running reports whether the dispatcher has been started and not stopped
+func (d *Dispatcher) Running() bool {
 	d.mu.RLock()
-	running := d.isRunning
-	d.mu.RUnlock()
+	defer d.mu.RUnlock()
+	return d.isRunning
+}
 
-	if !running {
+// IsHealthy returns true if dispatcher is operating normally
+func (d *Dispatcher) IsHealthy() bool {
+	if !d.Running() {
 		return false
 	}
 
-	// Check if queues are not completely full
-	highFull := len(d.highPriorityQueue) >= cap(d.highPriorityQueue)
-	normalFull := len(d.normalPriorityQueue) >= cap(d.normalPriorityQueue)
-	lowFull := len(d.lowPriorityQueue) >= cap(d.lowPriorityQueue)
-
 	// Unhealthy if all queues are full
-	if highFull && normalFull && lowFull {
-		return false
-	}
-
-	return true
+	return !d.queue.full()
 }
 
 // Capacity returns current capacity information
 func (d *Dispatcher) Capacity() (active, maxConcurrent, queued, maxQueued int) {
 	active = int(d.activeWorkers.Load())
-	maxConcurrent = d.config.MaxWorkers
+	maxConcurrent = d.config.Load().MaxWorkers
 
-	queued = len(d.highPriorityQueue) + len(d.normalPriorityQueue) + len(d.lowPriorityQueue)
-	maxQueued = cap(d.highPriorityQueue) + cap(d.normalPriorityQueue) + cap(d.lowPriorityQueue)
+	queued = d.queue.len()
+	maxQueued = d.queue.capacity()
 
 	return
 }
 
+// Fairness returns per-flow queuing stats and the fairness index across flows
+func (d *Dispatcher) Fairness() FairnessStats {
+	cfg := d.config.Load()
+	stats := d.queue.fairness(time.Now())
+	stats.Key = cfg.FairnessKey
+	stats.StarvationTimeoutMs = cfg.StarvationTimeout.Milliseconds()
+	return stats
+}
+
 // AvgQueueWaitMs returns average queue wait time in milliseconds
 func (d *Dispatcher) AvgQueueWaitMs() float64 {
 	count := atomic.LoadInt64(&d.metrics.RequestCount)
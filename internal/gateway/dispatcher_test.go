@@ -0,0 +1,276 @@
+package gateway
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+func TestLimiterAcquireWait(t *testing.T) {
+	ctx := context.Background()
+	tl := NewTenantLimiter()
+
+	if _, ok := tl.AcquireWait(ctx, "key-1", 1, 0); !ok {
+		t.Fatal("expected first slot to be acquired")
+	}
+	if current, ok := tl.AcquireWait(ctx, "key-1", 1, 0); ok || current != 1 {
+		t.Fatalf("AcquireWait = %d, %v; want 1, false at the limit", current, ok)
+	}
+
+	// A waiting caller gets the slot once it is released
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		tl.Release("key-1")
+	}()
+	if _, ok := tl.AcquireWait(ctx, "key-1", 1, time.Second); !ok {
+		t.Fatal("expected waiting caller to acquire the released slot")
+	}
+
+	// A limit of 0 only counts requests
+	for i := 0; i < 3; i++ {
+		if _, ok := tl.AcquireWait(ctx, "key-2", 0, 0); !ok {
+			t.Fatal("expected unlimited key to always acquire")
+		}
+	}
+	if stats := tl.Snapshot()["key-2"]; stats.Current != 3 || stats.Limit != 0 {
+		t.Errorf("snapshot = %+v, want 3 in flight and no limit", stats)
+	}
+}
+
+func TestCallerSlots(t *testing.T) {
+	ctx := context.Background()
+	d := NewDispatcher(DefaultDispatcherConfig(), nil)
+
+	// Every role of the key is limited, including those of its group
+	first := &DispatchRequest{APIKeyID: "key-1", RoleID: "direct", RoleLimits: map[string]int32{"direct": 0, "group-role": 1}}
+	if err := d.acquireCallerSlots(ctx, first); err != nil {
+		t.Fatalf("acquireCallerSlots = %v", err)
+	}
+	second := &DispatchRequest{APIKeyID: "key-2", RoleLimits: map[string]int32{"group-role": 1}}
+	if err := d.acquireCallerSlots(ctx, second); err != ErrRoleLimited {
+		t.Fatalf("acquireCallerSlots = %v, want ErrRoleLimited from the group's role", err)
+	}
+	// A rejected request holds no slots
+	if _, ok := d.APIKeyStats()["key-2"]; ok {
+		t.Error("expected the rejected request's key slot to be released")
+	}
+
+	// Limiters forget callers with nothing in flight
+	first.release()
+	if keys, roles := d.APIKeyStats(), d.RoleStats(); len(keys) != 0 || len(roles) != 0 {
+		t.Errorf("expected idle limiters to be evicted, got %v and %v", keys, roles)
+	}
+}
+
+type memQueueStore struct {
+	mu       sync.Mutex
+	requests map[string]*domain.QueuedRequest
+}
+
+func (m *memQueueStore) EnqueueRequest(ctx context.Context, q *domain.QueuedRequest) (*domain.QueuedRequest, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, existing := range m.requests {
+		if existing.APIKeyID == q.APIKeyID && existing.IdempotencyKey == q.IdempotencyKey && existing.Status != domain.QueuedRequestFailed {
+			c := *existing
+			return &c, nil
+		}
+	}
+	q.ID = q.IdempotencyKey
+	q.Status = domain.QueuedRequestQueued
+	c := *q
+	m.requests[q.ID] = &c
+	return nil, nil
+}
+
+func (m *memQueueStore) GetQueuedRequest(ctx context.Context, id string) (*domain.QueuedRequest, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	q, ok := m.requests[id]
+	if !ok {
+		return nil, nil
+	}
+	c := *q
+	return &c, nil
+}
+
+func (m *memQueueStore) ClaimQueuedRequest(ctx context.Context, id string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	q := m.requests[id]
+	if q == nil || q.Status != domain.QueuedRequestQueued {
+		return false, nil
+	}
+	q.Status = domain.QueuedRequestProcessing
+	return true, nil
+}
+
+func (m *memQueueStore) CompleteQueuedRequest(ctx context.Context, id string, resp *domain.ChatResponse, errMsg string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	q := m.requests[id]
+	q.Status, q.Response, q.Error = domain.QueuedRequestCompleted, resp, errMsg
+	if errMsg != "" {
+		q.Status = domain.QueuedRequestFailed
+	}
+	return nil
+}
+
+func (m *memQueueStore) FailStaleQueuedRequests(ctx context.Context, staleBefore time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (m *memQueueStore) ListQueuedRequests(ctx context.Context) ([]*domain.QueuedRequest, error) {
+	return nil, nil
+}
+
+func (m *memQueueStore) DeleteFinishedQueuedRequests(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func TestDurableQueueIdempotency(t *testing.T) {
+	ctx := context.Background()
+	store := &memQueueStore{requests: map[string]*domain.QueuedRequest{}}
+	d := NewDispatcher(DefaultDispatcherConfig(), nil)
+	d.SetDurableQueue(store, time.Hour)
+
+	store.requests["done"] = &domain.QueuedRequest{
+		ID: "done", IdempotencyKey: "done", APIKeyID: "key-1",
+		Status: domain.QueuedRequestCompleted, Response: &domain.ChatResponse{Content: "stored"},
+	}
+
+	// A retry with a completed key gets the stored response without being queued
+	result, err := d.Submit(ctx, &DispatchRequest{Ctx: ctx, ChatReq: &domain.ChatRequest{}, APIKeyID: "key-1", IdempotencyKey: "done"})
+	if err != nil || !result.Replayed || result.Response.Content != "stored" {
+		t.Fatalf("Submit = %+v, %v; want the stored response", result, err)
+	}
+	if depth := d.Stats().NormalPriorityQueueDepth; depth != 0 {
+		t.Fatalf("replayed request was queued (depth %d)", depth)
+	}
+
+	// Another API key may use the same idempotency key
+	req := &DispatchRequest{Ctx: ctx, ChatReq: &domain.ChatRequest{}, APIKeyID: "key-2", IdempotencyKey: "done", ResponseCh: make(chan *DispatchResult, 1)}
+	if result, err := d.enqueueDurable(ctx, req); result != nil || err != nil || req.durableID == "" {
+		t.Fatalf("enqueueDurable = %+v, %v; want the request stored", result, err)
+	}
+
+	// A worker that loses the claim waits for the outcome instead of processing
+	store.requests[req.durableID].Status = domain.QueuedRequestProcessing
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		store.CompleteQueuedRequest(ctx, req.durableID, &domain.ChatResponse{Content: "elsewhere"}, "")
+	}()
+	if d.claimDurable(req) {
+		t.Fatal("expected the claim to fail for a request processing elsewhere")
+	}
+	if got := <-req.ResponseCh; got.Response == nil || got.Response.Content != "elsewhere" {
+		t.Fatalf("got %+v, want the other worker's response", got)
+	}
+
+	// Nobody waits for a recovered request, so losing its claim just drops it
+	recovered := &DispatchRequest{Ctx: ctx, durableID: req.durableID, recovered: true, ResponseCh: make(chan *DispatchResult, 1)}
+	if d.claimDurable(recovered) || len(recovered.ResponseCh) != 0 {
+		t.Fatal("expected a recovered request that lost its claim to be dropped")
+	}
+}
+
+func TestFairQueue(t *testing.T) {
+	now := time.Now()
+	q := newFairQueue([numBands]int{10, 10, 10})
+	push := func(flow string, weight, priority int, age time.Duration) *DispatchRequest {
+		req := &DispatchRequest{Priority: priority, EnqueuedAt: now.Add(-age)}
+		if !q.push(req, flow, weight) {
+			t.Fatalf("push to %s rejected", flow)
+		}
+		return req
+	}
+	popFlows := func(n int) map[*DispatchRequest]bool {
+		popped := make(map[*DispatchRequest]bool)
+		for i := 0; i < n; i++ {
+			popped[q.pop(now, time.Minute)] = true
+		}
+		return popped
+	}
+
+	// A noisy flow that queued first doesn't hold up a quieter one in its band
+	var noisy []*DispatchRequest
+	for i := 0; i < 6; i++ {
+		noisy = append(noisy, push("role:noisy", 1, 5, time.Second))
+	}
+	quiet := push("role:quiet", 1, 5, 0)
+	if popped := popFlows(2); !popped[quiet] || !popped[noisy[0]] {
+		t.Errorf("expected the quiet flow's request among the first two dispatched")
+	}
+	popFlows(5)
+
+	// A flow with twice the weight gets twice the dispatches
+	var heavy []*DispatchRequest
+	for i := 0; i < 4; i++ {
+		heavy = append(heavy, push("role:heavy", 2, 5, 0))
+		push("role:light", 1, 5, 0)
+	}
+	popped := popFlows(3)
+	if !popped[heavy[0]] || !popped[heavy[1]] {
+		t.Errorf("expected two of the first three dispatches to go to the heavier flow")
+	}
+	popFlows(5)
+
+	// Higher bands go first, unless a lower one has waited past the starvation timeout
+	low := push("role:batch", 1, 0, 2*time.Minute)
+	high := push("role:interactive", 1, 9, 0)
+	if got := q.pop(now, time.Minute); got != low {
+		t.Errorf("expected the starved low-priority request first")
+	}
+	if got := q.pop(now, time.Minute); got != high {
+		t.Errorf("expected the high-priority request next")
+	}
+	if q.pop(now, time.Minute) != nil {
+		t.Errorf("expected the queue to be empty")
+	}
+
+	stats := q.fairness(now)
+	if stats.StarvationPromotions != 1 || stats.Flows["role:noisy"].Dispatched != 6 {
+		t.Errorf("fairness stats = %+v", stats)
+	}
+}
+
+func TestQueueStatus(t *testing.T) {
+	now := time.Now()
+	q := newFairQueue([numBands]int{10, 10, 10})
+	push := func(flow string, priority int) *DispatchRequest {
+		req := &DispatchRequest{Priority: priority, EnqueuedAt: now}
+		q.push(req, flow, 1)
+		return req
+	}
+
+	a1, a2 := push("role:a", 5), push("role:a", 5)
+	b1 := push("role:b", 5)
+	high := push("role:c", 9)
+
+	// The high band goes first; within a band, fair queuing puts b1 before a2
+	for _, tc := range []struct {
+		req  *DispatchRequest
+		want int
+	}{{high, 1}, {a1, 2}, {b1, 3}, {a2, 4}} {
+		if got, ok := q.position(tc.req); !ok || got != tc.want {
+			t.Errorf("position = %d, %v; want %d", got, ok, tc.want)
+		}
+	}
+	q.pop(now, 0)
+	if _, ok := q.position(high); ok {
+		t.Error("expected a dispatched request to have no position")
+	}
+	if got, _ := q.position(a2); got != 3 {
+		t.Errorf("position after a dispatch = %d, want 3", got)
+	}
+
+	if got := estimateQueueWait(3, 0, 2); got != 0 {
+		t.Errorf("expected no estimate without processing history, got %v", got)
+	}
+	if got := estimateQueueWait(5, 200, 2); got != 600*time.Millisecond {
+		t.Errorf("estimateQueueWait = %v, want 600ms for three rounds of two workers", got)
+	}
+}
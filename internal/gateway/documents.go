@@ -0,0 +1,217 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"modelgate/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// documentsInstructions introduces the numbered sources added to the system
+// prompt for providers without native document support
+const documentsInstructions = "Answer using the sources below. Right after each statement drawn from them, cite the sources " +
+	"supporting it by number in square brackets, such as [1] or [1, 3]. If the sources don't contain the answer, say so."
+
+// transcribeInstructions asks a vision model for the text of an image
+const transcribeInstructions = "Transcribe all text in the image exactly as written, in reading order. " +
+	"Keep headings, list items and table rows on their own lines. Reply with the transcription only, or nothing if the image has no text."
+
+// maxCitationMarker is the longest bracketed citation marker, such as "[12, 13, 14]"
+const maxCitationMarker = 24
+
+// emulatesDocuments reports whether the gateway has to ground the request in
+// its documents itself because the client can't
+func emulatesDocuments(client domain.LLMClient, req *domain.ChatRequest) bool {
+	if len(req.Documents) == 0 {
+		return false
+	}
+	capable, ok := client.(domain.DocumentCapable)
+	return !ok || !capable.SupportsDocuments()
+}
+
+// withDocumentsPrompt returns a copy of req whose system prompt carries the
+// documents as numbered sources, without the documents themselves
+func withDocumentsPrompt(req *domain.ChatRequest) *domain.ChatRequest {
+	var b strings.Builder
+	b.WriteString(documentsInstructions)
+	for i, doc := range req.Documents {
+		title := doc.AdditionalProps["title"]
+		if title == "" {
+			title = doc.ID
+		}
+		fmt.Fprintf(&b, "\n\n[%d]", i+1)
+		if title != "" {
+			b.WriteString(" " + title)
+		}
+		if page := doc.AdditionalProps["page"]; page != "" {
+			b.WriteString(" (page " + page + ")")
+		}
+		b.WriteString("\n" + doc.Text)
+	}
+
+	out := *req
+	out.Documents = nil
+	if out.SystemPrompt != "" {
+		out.SystemPrompt += "\n\n" + b.String()
+	} else {
+		out.SystemPrompt = b.String()
+	}
+	return &out
+}
+
+// documentCiter turns the [n] markers of an answer grounded in numbered
+// sources into citations of the documents, as the answer arrives. A marker
+// cites the sentence before it, or the text since the previous marker.
+type documentCiter struct {
+	docs     []domain.Document
+	content  []rune
+	pos      int    // Content before this has been scanned for markers
+	lastEnd  int    // End of the previous marker
+	lastSpan [2]int // Span cited by the previous marker
+}
+
+// push adds text to the answer and returns the citations it completed
+func (c *documentCiter) push(text string) []domain.Citation {
+	c.content = append(c.content, []rune(text)...)
+	var citations []domain.Citation
+	for c.pos < len(c.content) {
+		open := indexRune(c.content, c.pos, '[')
+		if open < 0 {
+			c.pos = len(c.content)
+			break
+		}
+		end := indexRune(c.content, open, ']')
+		if end < 0 {
+			if len(c.content)-open <= maxCitationMarker {
+				c.pos = open // The marker may still be arriving
+				break
+			}
+			c.pos = open + 1
+			continue
+		}
+		sources, ok := c.sources(string(c.content[open+1 : end]))
+		if !ok {
+			c.pos = open + 1
+			continue
+		}
+		start, stop := c.span(open)
+		c.pos, c.lastEnd = end+1, end+1
+		if start < stop {
+			citations = append(citations, domain.Citation{
+				Start:   start,
+				End:     stop,
+				Text:    string(c.content[start:stop]),
+				Sources: sources,
+			})
+		}
+	}
+	return citations
+}
+
+// sources returns the IDs of the documents a marker's numbers refer to
+func (c *documentCiter) sources(marker string) ([]string, bool) {
+	var ids []string
+	seen := map[int]bool{}
+	for _, part := range strings.Split(marker, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || n < 1 || n > len(c.docs) {
+			return nil, false
+		}
+		if !seen[n] {
+			seen[n] = true
+			ids = append(ids, c.docs[n-1].ID)
+		}
+	}
+	return ids, len(ids) > 0
+}
+
+// span returns the text cited by a marker starting at open
+func (c *documentCiter) span(open int) (int, int) {
+	end := open
+	for end > c.lastEnd && unicode.IsSpace(c.content[end-1]) {
+		end--
+	}
+	if end == c.lastEnd && c.lastEnd > 0 {
+		return c.lastSpan[0], c.lastSpan[1] // Adjacent markers cite the same text
+	}
+	start := end
+	for start > c.lastEnd && strings.ContainsRune(".!?", c.content[start-1]) {
+		start--
+	}
+	for start > c.lastEnd && !strings.ContainsRune(".!?\n", c.content[start-1]) {
+		start--
+	}
+	for start < end && unicode.IsSpace(c.content[start]) {
+		start++
+	}
+	c.lastSpan = [2]int{start, end}
+	return start, end
+}
+
+func indexRune(runes []rune, from int, r rune) int {
+	for i := from; i < len(runes); i++ {
+		if runes[i] == r {
+			return i
+		}
+	}
+	return -1
+}
+
+// citeDocuments passes a stream through, following each text chunk with the
+// citations of the documents it completed
+func citeDocuments(events <-chan domain.StreamEvent, docs []domain.Document) <-chan domain.StreamEvent {
+	out := make(chan domain.StreamEvent, 100)
+	go func() {
+		defer close(out)
+		citer := &documentCiter{docs: docs}
+		for event := range events {
+			out <- event
+			if chunk, ok := event.(domain.TextChunk); ok {
+				for _, citation := range citer.push(chunk.Content) {
+					out <- domain.CitationEvent{Citation: citation}
+				}
+			}
+		}
+	}()
+	return out
+}
+
+// Transcribe returns the text of an image, read by a vision model, for
+// document ingestion (implements documents.OCR). Usage is recorded against
+// the API key that uploaded the document.
+func (s *Service) Transcribe(ctx context.Context, model string, image []byte, mediaType, apiKeyID string) (string, error) {
+	model = s.config.Load().ResolveModel(model)
+	client, err := s.getClientForTenant(ctx, "", "default", model)
+	if err != nil {
+		return "", fmt.Errorf("OCR model: %w", err)
+	}
+	req := &domain.ChatRequest{
+		Model:        model,
+		SystemPrompt: transcribeInstructions,
+		Messages: []domain.Message{{
+			Role:    "user",
+			Content: []domain.ContentBlock{{Type: "image", ImageData: image, MediaType: mediaType}},
+		}},
+		RequestID: uuid.New().String(),
+		APIKeyID:  apiKeyID,
+	}
+	startTime := time.Now()
+	resp, err := client.ChatComplete(ctx, req)
+	if err != nil {
+		return "", fmt.Errorf("transcribe: %w", err)
+	}
+	if resp.Usage != nil && s.usageRepo != nil {
+		var cost float64
+		if price := s.PriceFor(ctx, model); price != nil {
+			cost = price.Cost(int64(resp.Usage.PromptTokens), int64(resp.Usage.CompletionTokens))
+		}
+		s.recordUsage(ctx, req, resp.Content, int64(resp.Usage.PromptTokens), int64(resp.Usage.CompletionTokens), cost, time.Since(startTime), true, "")
+	}
+	return strings.TrimSpace(resp.Content), nil
+}
@@ -0,0 +1,57 @@
+package gateway
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"modelgate/internal/domain"
+)
+
+func TestDocumentCiter(t *testing.T) {
+	citer := &documentCiter{docs: []domain.Document{{ID: "a"}, {ID: "b"}, {ID: "c"}}}
+	var citations []domain.Citation
+	// Markers arrive split across chunks; unknown sources aren't citations
+	for _, chunk := range []string{"Refunds take 30 days. Orders ship from Lyon [", "1, 3", "][2]. See item [7] and [x", "]. Done [2]"} {
+		citations = append(citations, citer.push(chunk)...)
+	}
+
+	want := []domain.Citation{
+		{Start: 22, End: 43, Text: "Orders ship from Lyon", Sources: []string{"a", "c"}},
+		{Start: 22, End: 43, Text: "Orders ship from Lyon", Sources: []string{"b"}},
+		{Start: 77, End: 81, Text: "Done", Sources: []string{"b"}},
+	}
+	if !reflect.DeepEqual(citations, want) {
+		t.Fatalf("got citations %+v, want %+v", citations, want)
+	}
+}
+
+func TestChatCompleteEmulatesDocuments(t *testing.T) {
+	client := &scriptedClient{replies: []string{"Items can be returned within 30 days [2]."}}
+	req := &domain.ChatRequest{
+		Model:        "openai/gpt-4o",
+		SystemPrompt: "Be brief.",
+		Messages:     []domain.Message{{Role: "user", Content: []domain.ContentBlock{{Type: "text", Text: "Refund window?"}}}},
+		Documents: []domain.Document{
+			{ID: "shipping", Text: "Orders ship in two days."},
+			{ID: "doc-1#0", Text: "Returns are accepted within 30 days.", AdditionalProps: map[string]string{"title": "policy.pdf", "page": "3"}},
+		},
+	}
+
+	resp, err := (&Service{}).chatComplete(context.Background(), client, req)
+	if err != nil {
+		t.Fatalf("chatComplete failed: %v", err)
+	}
+	prompt := client.seen[0].SystemPrompt
+	if !strings.HasPrefix(prompt, "Be brief.\n\n") || !strings.Contains(prompt, "[1] shipping\nOrders ship") ||
+		!strings.Contains(prompt, "[2] policy.pdf (page 3)\nReturns are accepted") {
+		t.Fatalf("unexpected sources prompt %q", prompt)
+	}
+	if len(client.seen[0].Documents) != 0 {
+		t.Fatal("expected the documents to be moved into the prompt")
+	}
+	if len(resp.Citations) != 1 || resp.Citations[0].Text != "Items can be returned within 30 days" || resp.Citations[0].Sources[0] != "doc-1#0" {
+		t.Fatalf("unexpected citations %+v", resp.Citations)
+	}
+}
@@ -0,0 +1,226 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+
+	"modelgate/internal/domain"
+)
+
+const (
+	// durablePollInterval is how often a duplicate request checks on the
+	// request it is waiting for
+	durablePollInterval = 250 * time.Millisecond
+	// durableStaleAfter is how long a request may be processing before a
+	// restarting dispatcher assumes its worker is gone and fails it
+	durableStaleAfter = 15 * time.Minute
+	// durableCleanupInterval is how often finished requests past retention are deleted
+	durableCleanupInterval = 10 * time.Minute
+)
+
+// QueueStore persists non-streaming requests for the durable queue (implemented by postgres.TenantStore)
+type QueueStore interface {
+	EnqueueRequest(ctx context.Context, q *domain.QueuedRequest) (*domain.QueuedRequest, error)
+	GetQueuedRequest(ctx context.Context, id string) (*domain.QueuedRequest, error)
+	ClaimQueuedRequest(ctx context.Context, id string) (bool, error)
+	CompleteQueuedRequest(ctx context.Context, id string, resp *domain.ChatResponse, errMsg string) error
+	FailStaleQueuedRequests(ctx context.Context, staleBefore time.Time) (int64, error)
+	ListQueuedRequests(ctx context.Context) ([]*domain.QueuedRequest, error)
+	DeleteFinishedQueuedRequests(ctx context.Context, before time.Time) (int64, error)
+}
+
+// SetDurableQueue persists accepted non-streaming requests in store until they
+// are processed. On Start, requests left queued by a previous run are processed
+// again. Finished requests are kept for retention so that retries with the same
+// idempotency key get the stored response. Call before Start.
+func (d *Dispatcher) SetDurableQueue(store QueueStore, retention time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.queueStore = store
+	d.queueRetention = retention
+}
+
+// enqueueDurable stores a non-streaming request before it is queued in memory.
+// It returns a result when the request's idempotency key was already used by a
+// request that completed, or that is still running elsewhere.
+func (d *Dispatcher) enqueueDurable(ctx context.Context, req *DispatchRequest) (*DispatchResult, error) {
+	key := req.IdempotencyKey
+	if key == "" {
+		key = uuid.New().String()
+	}
+	q := &domain.QueuedRequest{
+		IdempotencyKey: key,
+		APIKeyID:       req.APIKeyID,
+		RoleID:         req.RoleID,
+		GroupID:        req.GroupID,
+		Priority:       req.Priority,
+		Request:        req.ChatReq,
+	}
+	existing, err := d.queueStore.EnqueueRequest(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		atomic.AddInt64(&d.metrics.RequestsDeduplicated, 1)
+		return d.awaitDurable(ctx, existing)
+	}
+	req.durableID = q.ID
+	return nil, nil
+}
+
+// awaitDurable waits for a stored request to finish and returns its outcome
+func (d *Dispatcher) awaitDurable(ctx context.Context, q *domain.QueuedRequest) (*DispatchResult, error) {
+	timeout := time.NewTimer(d.config.Load().QueueTimeout)
+	defer timeout.Stop()
+	ticker := time.NewTicker(durablePollInterval)
+	defer ticker.Stop()
+
+	for {
+		switch q.Status {
+		case domain.QueuedRequestCompleted:
+			return &DispatchResult{Response: q.Response, Replayed: true}, nil
+		case domain.QueuedRequestFailed:
+			return &DispatchResult{Error: errors.New(q.Error)}, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-d.shutdownCh:
+			return nil, ErrShuttingDown
+		case <-timeout.C:
+			atomic.AddInt64(&d.metrics.RequestsTimedOut, 1)
+			return nil, ErrQueueTimeout
+		case <-ticker.C:
+		}
+
+		next, err := d.queueStore.GetQueuedRequest(ctx, q.ID)
+		if err != nil {
+			return nil, err
+		}
+		if next == nil {
+			return nil, errors.New("queued request was deleted")
+		}
+		q = next
+	}
+}
+
+// claimDurable claims a stored request for this worker. When another instance
+// got to it first, it waits for that outcome instead, so the request is only
+// sent to the provider once. Returns false if the request must not be
+// processed here.
+func (d *Dispatcher) claimDurable(req *DispatchRequest) bool {
+	if req.durableID == "" {
+		return true
+	}
+	ok, err := d.queueStore.ClaimQueuedRequest(req.Ctx, req.durableID)
+	if err != nil {
+		slog.Error("Failed to claim queued request", "id", req.durableID, "error", err)
+		req.ResponseCh <- &DispatchResult{Error: err}
+		return false
+	}
+	if ok {
+		return true
+	}
+	if req.recovered {
+		// Nobody is waiting for a recovered request
+		return false
+	}
+
+	q, err := d.queueStore.GetQueuedRequest(req.Ctx, req.durableID)
+	var result *DispatchResult
+	if err == nil && q != nil {
+		result, err = d.awaitDurable(req.Ctx, q)
+	}
+	if err != nil {
+		result = &DispatchResult{Error: err}
+	}
+	req.ResponseCh <- result
+	return false
+}
+
+// completeDurable records the outcome of a stored request
+func (d *Dispatcher) completeDurable(req *DispatchRequest, resp *domain.ChatResponse, err error) {
+	if req.durableID == "" {
+		return
+	}
+	errMsg := ""
+	if err != nil {
+		errMsg = err.Error()
+	}
+	if err := d.queueStore.CompleteQueuedRequest(context.WithoutCancel(req.Ctx), req.durableID, resp, errMsg); err != nil {
+		slog.Error("Failed to record queued request outcome", "id", req.durableID, "error", err)
+	}
+}
+
+// recoverDurable processes the requests a previous run accepted but did not
+// process. Nobody waits for their responses; the outcome is stored for
+// clients that retry with the same idempotency key.
+func (d *Dispatcher) recoverDurable() {
+	ctx := context.Background()
+	if n, err := d.queueStore.FailStaleQueuedRequests(ctx, time.Now().Add(-durableStaleAfter)); err != nil {
+		slog.Error("Failed to fail interrupted queued requests", "error", err)
+	} else if n > 0 {
+		slog.Warn("Failed queued requests interrupted by a restart", "count", n)
+	}
+
+	requests, err := d.queueStore.ListQueuedRequests(ctx)
+	if err != nil {
+		slog.Error("Failed to recover queued requests", "error", err)
+		return
+	}
+	if len(requests) == 0 {
+		return
+	}
+	slog.Info("Recovering queued requests", "count", len(requests))
+
+	for _, q := range requests {
+		req := &DispatchRequest{
+			Ctx:        ctx,
+			ChatReq:    q.Request,
+			TenantSlug: "default",
+			APIKeyID:   q.APIKeyID,
+			RoleID:     q.RoleID,
+			GroupID:    q.GroupID,
+			Priority:   q.Priority,
+			ResponseCh: make(chan *DispatchResult, 1),
+			EnqueuedAt: q.EnqueuedAt,
+			durableID:  q.ID,
+			recovered:  true,
+		}
+		// Wait for room rather than failing requests that were already accepted
+		for !d.enqueue(req) {
+			select {
+			case <-time.After(100 * time.Millisecond):
+			case <-d.shutdownCh:
+				// Still queued; the next start picks it up
+				return
+			}
+		}
+		atomic.AddInt64(&d.metrics.RequestsRecovered, 1)
+	}
+}
+
+// cleanupDurable deletes finished requests once they are past retention
+func (d *Dispatcher) cleanupDurable() {
+	ticker := time.NewTicker(durableCleanupInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-d.shutdownCh:
+			return
+		case <-ticker.C:
+			n, err := d.queueStore.DeleteFinishedQueuedRequests(context.Background(), time.Now().Add(-d.queueRetention))
+			if err != nil {
+				slog.Error("Failed to clean up queued requests", "error", err)
+			} else if n > 0 {
+				slog.Debug("Deleted finished queued requests", "count", n)
+			}
+		}
+	}
+}
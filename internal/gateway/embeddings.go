@@ -0,0 +1,151 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"modelgate/internal/domain"
+	"modelgate/internal/provider"
+	"modelgate/internal/telemetry"
+)
+
+// Embed generates embeddings for req.Input. When the model's provider is
+// down, throttled or out of quota, the models listed for it under
+// [embedding_routing] fallbacks are tried in order. Usage and cost are
+// recorded against the model that made the embeddings, as for chat.
+func (s *Service) Embed(ctx context.Context, req *domain.EmbeddingRequest) (*domain.EmbeddingResponse, error) {
+	cfg := s.config.Load()
+	model := cfg.ResolveModel(req.Model)
+	fallbacks, ok := cfg.Embeddings.Fallbacks[model]
+	if !ok {
+		fallbacks = cfg.Embeddings.Fallbacks[req.Model]
+	}
+	candidates := append([]string{model}, fallbacks...)
+
+	startTime := time.Now()
+	var lastErr *domain.ProviderError
+	for i, candidate := range candidates {
+		resp, err := s.embedWith(ctx, candidate, req)
+		if err == nil {
+			if candidate != model {
+				resp.FallbackFrom = model
+			}
+			s.recordEmbeddingUsage(ctx, req, resp, time.Since(startTime))
+			return resp, nil
+		}
+		lastErr = err
+		if i == len(candidates)-1 || !embeddingFailover(err) {
+			break
+		}
+		if s.metrics != nil {
+			s.metrics.RecordEmbeddingFallback(candidate, candidates[i+1], err.Code)
+		}
+		slog.Warn("Embedding model failed, trying its fallback",
+			"model", candidate,
+			"fallback", candidates[i+1],
+			"code", err.Code,
+			"request_id", req.RequestID)
+	}
+
+	if s.usageRepo != nil {
+		usageReq := &domain.ChatRequest{Model: model, RequestID: req.RequestID, APIKeyID: req.APIKeyID, ProjectID: req.ProjectID,
+			Embedding: &domain.EmbeddingMetadata{Inputs: len(req.Input)}}
+		s.recordUsage(ctx, usageReq, "", 0, 0, 0, time.Since(startTime), false, lastErr.Code)
+	}
+	return nil, lastErr
+}
+
+// embedWith sends the request to one model, recording the outcome against its
+// provider and API key
+func (s *Service) embedWith(ctx context.Context, model string, req *domain.EmbeddingRequest) (*domain.EmbeddingResponse, *domain.ProviderError) {
+	providerType, known := s.config.Load().GetProviderForModel(model)
+	var recorder *telemetry.RequestRecorder
+	if s.metrics != nil {
+		recorder = s.metrics.NewRequestRecorder("Embed", model, req.TenantID, string(providerType))
+	}
+	fail := func(err error) *domain.ProviderError {
+		perr := provider.NormalizeError(providerType, err)
+		if perr.Code == "" {
+			perr.Code = domain.ErrorCodeProviderError
+		}
+		if recorder != nil {
+			recorder.RecordError(perr.Code)
+		}
+		if s.healthTracker != nil && known {
+			s.healthTracker.RecordFailure(ctx, "", string(providerType), model, perr.Code)
+		}
+		return perr
+	}
+
+	// Model names without a provider are left to the provider manager to infer
+	var client domain.LLMClient
+	var keyID string
+	var err error
+	if known {
+		client, keyID, err = s.selectClient(ctx, "", "default", model)
+	} else {
+		client, err = s.providers.GetClientForModel(model)
+	}
+	if err != nil {
+		return nil, fail(fmt.Errorf("getting provider client: %w", err))
+	}
+
+	startTime := time.Now()
+	embeddings, tokens, err := client.Embed(ctx, model, req.Input, req.Dimensions)
+	s.recordKeyOutcome(ctx, keyID, err)
+	if err != nil {
+		return nil, fail(err)
+	}
+	if len(embeddings) != len(req.Input) {
+		return nil, fail(fmt.Errorf("%s returned %d embeddings for %d inputs", model, len(embeddings), len(req.Input)))
+	}
+	if tokens == 0 {
+		// Some providers don't report usage; estimate it so the request is priced
+		for _, text := range req.Input {
+			tokens += int64(len(text)+3) / 4
+		}
+	}
+
+	resp := &domain.EmbeddingResponse{
+		Embeddings:   embeddings,
+		Model:        model,
+		Provider:     providerType,
+		PromptTokens: tokens,
+	}
+	if price := s.PriceFor(ctx, model); price != nil {
+		resp.CostUSD = price.Cost(tokens, 0)
+	}
+	s.recordKeyUsage(ctx, keyID, tokens, 0, resp.CostUSD)
+	if recorder != nil {
+		recorder.RecordSuccess(tokens, 0, resp.CostUSD)
+	}
+	if s.healthTracker != nil && known {
+		s.healthTracker.RecordSuccess(ctx, "", string(providerType), model, int(time.Since(startTime).Milliseconds()))
+	}
+	return resp, nil
+}
+
+// embeddingFailover reports whether an embedding error is one another
+// provider may not share
+func embeddingFailover(err *domain.ProviderError) bool {
+	switch err.Code {
+	case domain.ErrorCodeRateLimit, domain.ErrorCodeInsufficientQuota, domain.ErrorCodeOverloaded,
+		domain.ErrorCodeTimeout, domain.ErrorCodeProviderError, domain.ErrorCodeModelNotFound:
+		return true
+	}
+	return false
+}
+
+func (s *Service) recordEmbeddingUsage(ctx context.Context, req *domain.EmbeddingRequest, resp *domain.EmbeddingResponse, latency time.Duration) {
+	if s.usageRepo == nil {
+		return
+	}
+	meta := &domain.EmbeddingMetadata{Inputs: len(req.Input), FallbackFrom: resp.FallbackFrom}
+	if len(resp.Embeddings) > 0 {
+		meta.Dimensions = len(resp.Embeddings[0])
+	}
+	usageReq := &domain.ChatRequest{Model: resp.Model, RequestID: req.RequestID, APIKeyID: req.APIKeyID, ProjectID: req.ProjectID, Embedding: meta}
+	s.recordUsage(ctx, usageReq, "", resp.PromptTokens, 0, resp.CostUSD, latency, true, "")
+}
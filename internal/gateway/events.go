@@ -4,6 +4,7 @@ import (
 	"context"
 	"log/slog"
 
+	"modelgate/internal/cache/semantic"
 	"modelgate/internal/domain"
 )
 
@@ -88,11 +89,17 @@ func (s *Service) recordToolCallEvent(ctx context.Context, tenantID, apiKeyID, t
 }
 
 // recordCacheHitEvent records a cache hit event to both metrics and database
-func (s *Service) recordCacheHitEvent(ctx context.Context, tenantID, apiKeyID, model string, tokensSaved int64, costSaved float64) {
+func (s *Service) recordCacheHitEvent(ctx context.Context, tenantID string, req *domain.ChatRequest, provider string, cached *semantic.CacheResult) {
+	apiKeyID, model := req.APIKeyID, req.Model
+	var tokensSaved int64
+	if usage := cached.Response.Usage; usage != nil {
+		tokensSaved = int64(usage.PromptTokens + usage.CompletionTokens)
+	}
+	costSaved := cached.Response.CostUSD
+
 	// Record to Prometheus metrics
 	if s.metrics != nil {
-		roleID := "" // Extract from request if available
-		s.metrics.RecordCacheHit(model, tenantID, roleID, tokensSaved, costSaved)
+		s.metrics.RecordCacheHit(model, provider, tenantID, req.RoleID, cached.Similarity, tokensSaved, costSaved)
 	}
 
 	// Persist to PostgreSQL
@@ -126,11 +133,12 @@ func (s *Service) recordCacheHitEvent(ctx context.Context, tenantID, apiKeyID, m
 }
 
 // recordCacheMissEvent records a cache miss event to both metrics and database
-func (s *Service) recordCacheMissEvent(ctx context.Context, tenantID, apiKeyID, model string) {
+func (s *Service) recordCacheMissEvent(ctx context.Context, tenantID string, req *domain.ChatRequest, provider string) {
+	apiKeyID, model := req.APIKeyID, req.Model
+
 	// Record to Prometheus metrics
 	if s.metrics != nil {
-		roleID := "" // Extract from request if available
-		s.metrics.RecordCacheMiss(model, tenantID, roleID)
+		s.metrics.RecordCacheMiss(model, provider, tenantID, req.RoleID)
 	}
 
 	// Persist to PostgreSQL
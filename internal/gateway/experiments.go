@@ -0,0 +1,33 @@
+package gateway
+
+import (
+	"log/slog"
+
+	"modelgate/internal/domain"
+	"modelgate/internal/experiments"
+)
+
+// SetExperiments sets the experiments that assign requests to variants
+func (s *Service) SetExperiments(svc *experiments.Service) {
+	s.experiments = svc
+}
+
+// assignExperiment applies the experiment variant a request draws, if any.
+// Replays keep the replayed request as it was.
+func (s *Service) assignExperiment(req *domain.ChatRequest) {
+	if req.ReplayOf != "" {
+		return
+	}
+	a := s.experiments.Assign(req)
+	if a == nil {
+		return
+	}
+	if a.Model != "" {
+		req.Model = s.config.Load().ResolveModel(req.Model)
+	}
+	slog.Debug("Request assigned to experiment variant",
+		"request_id", req.RequestID,
+		"experiment_id", a.ExperimentID,
+		"variant", a.Variant,
+		"model", req.Model)
+}
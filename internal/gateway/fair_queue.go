@@ -0,0 +1,336 @@
+package gateway
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Fairness keys: what a queued request's flow is keyed by
+const (
+	FairnessKeyRole   = "role"    // Requests of one role share a flow; keys without a role get their own
+	FairnessKeyAPIKey = "api_key" // Every API key is its own flow
+)
+
+// fairnessDecay is the time constant of the recent dispatch counts behind the
+// fairness index, so the index reflects the last few minutes rather than all time
+const fairnessDecay = time.Minute
+
+// Priority bands, in the order they are served
+const (
+	bandHigh = iota
+	bandNormal
+	bandLow
+	numBands
+)
+
+// bandFor returns the band a priority (0-10) is queued in
+func bandFor(priority int) int {
+	switch {
+	case priority >= 8:
+		return bandHigh
+	case priority >= 4:
+		return bandNormal
+	default:
+		return bandLow
+	}
+}
+
+// fairQueue holds requests waiting for a worker. Higher bands are served
+// first. Within a band, flows (roles or API keys) are served by weighted fair
+// queuing: each request gets a virtual finish time of 1/weight after its
+// flow's previous one, and the earliest finish time goes next, so a flow
+// flooding the queue only delays itself. A request that has waited longer
+// than the starvation timeout is served next whatever its band.
+type fairQueue struct {
+	mu       sync.Mutex
+	bands    [numBands]fairBand
+	stats    map[string]*FlowStats
+	promoted int64  // Requests served early by starvation protection
+	pushed   uint64 // Requests ever queued, to order entries with equal tags
+}
+
+type fairBand struct {
+	capacity int
+	size     int
+	vtime    float64 // Start tag of the last dispatched request
+	flows    map[string]*fairFlow
+}
+
+type fairFlow struct {
+	weight     float64
+	lastFinish float64
+	queue      []*fairEntry
+}
+
+type fairEntry struct {
+	req    *DispatchRequest
+	flow   string
+	start  float64
+	finish float64
+	seq    uint64
+}
+
+// FlowStats describes one flow of the fair queue
+type FlowStats struct {
+	Weight      int     `json:"weight"`
+	Queued      int     `json:"queued"`
+	Dispatched  int64   `json:"dispatched"`
+	Promoted    int64   `json:"starvation_promotions"` // Dispatched early because they waited too long
+	AvgWaitMs   float64 `json:"avg_queue_wait_ms"`
+	MaxWaitMs   int64   `json:"max_queue_wait_ms"`
+	RecentShare float64 `json:"recent_share"` // Share of recent dispatches
+
+	totalWaitMs int64
+	recent      float64 // Dispatches, decayed by fairnessDecay
+	recentAt    time.Time
+}
+
+// FairnessStats summarizes how evenly queued requests are served
+type FairnessStats struct {
+	Key                  string `json:"key"`
+	StarvationTimeoutMs  int64  `json:"starvation_timeout_ms"`
+	StarvationPromotions int64  `json:"starvation_promotions"`
+	// Jain's fairness index of recent weighted dispatches across flows that
+	// have requests queued: 1 when each gets service in proportion to its
+	// weight, approaching 1/n when one flow gets everything
+	Index float64              `json:"index"`
+	Flows map[string]FlowStats `json:"flows"`
+}
+
+func newFairQueue(capacities [numBands]int) *fairQueue {
+	q := &fairQueue{stats: make(map[string]*FlowStats)}
+	for i := range q.bands {
+		q.bands[i] = fairBand{capacity: capacities[i], flows: make(map[string]*fairFlow)}
+	}
+	return q
+}
+
+// push queues a request in flow with the given weight (<= 0 counts as 1). It
+// returns false when the request's band is full.
+func (q *fairQueue) push(req *DispatchRequest, flow string, weight int) bool {
+	if weight <= 0 {
+		weight = 1
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	band := &q.bands[bandFor(req.Priority)]
+	if band.size >= band.capacity {
+		return false
+	}
+	f := band.flows[flow]
+	if f == nil {
+		f = &fairFlow{}
+		band.flows[flow] = f
+	}
+	f.weight = float64(weight)
+
+	// A flow that went idle gets no credit for the time it wasn't queued
+	start := math.Max(band.vtime, f.lastFinish)
+	q.pushed++
+	entry := &fairEntry{req: req, flow: flow, start: start, finish: start + 1/f.weight, seq: q.pushed}
+	f.lastFinish = entry.finish
+	f.queue = append(f.queue, entry)
+	band.size++
+
+	stats := q.flowStats(flow)
+	stats.Weight = weight
+	stats.Queued++
+	return true
+}
+
+// pop removes and returns the next request to process, or nil if the queue
+// is empty. starvation <= 0 disables starvation protection.
+func (q *fairQueue) pop(now time.Time, starvation time.Duration) *DispatchRequest {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	band, next := q.nextFair()
+	if next == nil {
+		return nil
+	}
+	if starvation > 0 {
+		if b, oldest := q.oldest(); oldest != nil && oldest != next && now.Sub(oldest.req.EnqueuedAt) > starvation {
+			band, next = b, oldest
+			q.promoted++
+			q.flowStats(next.flow).Promoted++
+		}
+	}
+
+	f := band.flows[next.flow]
+	f.queue = f.queue[1:]
+	if len(f.queue) == 0 {
+		delete(band.flows, next.flow)
+	}
+	band.size--
+	band.vtime = math.Max(band.vtime, next.start)
+
+	stats := q.flowStats(next.flow)
+	stats.Queued--
+	stats.Dispatched++
+	waitMs := now.Sub(next.req.EnqueuedAt).Milliseconds()
+	stats.totalWaitMs += waitMs
+	if waitMs > stats.MaxWaitMs {
+		stats.MaxWaitMs = waitMs
+	}
+	stats.recent = decayed(stats.recent, stats.recentAt, now) + 1
+	stats.recentAt = now
+	return next.req
+}
+
+// nextFair returns the head with the earliest finish tag in the highest non-empty band
+func (q *fairQueue) nextFair() (*fairBand, *fairEntry) {
+	for i := range q.bands {
+		band := &q.bands[i]
+		var next *fairEntry
+		for _, f := range band.flows {
+			head := f.queue[0]
+			if next == nil || head.finish < next.finish ||
+				(head.finish == next.finish && head.seq < next.seq) {
+				next = head
+			}
+		}
+		if next != nil {
+			return band, next
+		}
+	}
+	return nil, nil
+}
+
+// oldest returns the longest-waiting request. Flows are FIFO, so it is one of the heads.
+func (q *fairQueue) oldest() (*fairBand, *fairEntry) {
+	var band *fairBand
+	var oldest *fairEntry
+	for i := range q.bands {
+		for _, f := range q.bands[i].flows {
+			if head := f.queue[0]; oldest == nil || head.req.EnqueuedAt.Before(oldest.req.EnqueuedAt) {
+				band, oldest = &q.bands[i], head
+			}
+		}
+	}
+	return band, oldest
+}
+
+func (q *fairQueue) flowStats(flow string) *FlowStats {
+	stats := q.stats[flow]
+	if stats == nil {
+		stats = &FlowStats{Weight: 1}
+		q.stats[flow] = stats
+	}
+	return stats
+}
+
+// position returns how many requests are ahead of req plus one (1 = next),
+// and false once req is no longer queued. It follows the fair queuing order;
+// starvation promotions can still move older requests ahead.
+func (q *fairQueue) position(req *DispatchRequest) (int, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	b := bandFor(req.Priority)
+	var entry *fairEntry
+	for _, f := range q.bands[b].flows {
+		for _, e := range f.queue {
+			if e.req == req {
+				entry = e
+				break
+			}
+		}
+	}
+	if entry == nil {
+		return 0, false
+	}
+
+	ahead := 0
+	for i := 0; i < b; i++ {
+		ahead += q.bands[i].size
+	}
+	for _, f := range q.bands[b].flows {
+		for _, e := range f.queue {
+			if e.finish < entry.finish || (e.finish == entry.finish && e.seq < entry.seq) {
+				ahead++
+			}
+		}
+	}
+	return ahead + 1, true
+}
+
+// len returns the number of queued requests
+func (q *fairQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	n := 0
+	for i := range q.bands {
+		n += q.bands[i].size
+	}
+	return n
+}
+
+// capacity returns the total number of requests the queue can hold
+func (q *fairQueue) capacity() int {
+	n := 0
+	for i := range q.bands {
+		n += q.bands[i].capacity
+	}
+	return n
+}
+
+// full reports whether every band is full
+func (q *fairQueue) full() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i := range q.bands {
+		if q.bands[i].size < q.bands[i].capacity {
+			return false
+		}
+	}
+	return true
+}
+
+// fairness returns the per-flow stats and fairness index as of now
+func (q *fairQueue) fairness(now time.Time) FairnessStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	result := FairnessStats{
+		StarvationPromotions: q.promoted,
+		Index:                1,
+		Flows:                make(map[string]FlowStats, len(q.stats)),
+	}
+	var totalRecent, sum, sumSquares float64
+	backlogged := 0
+	for _, stats := range q.stats {
+		totalRecent += decayed(stats.recent, stats.recentAt, now)
+	}
+	for flow, stats := range q.stats {
+		s := *stats
+		recent := decayed(stats.recent, stats.recentAt, now)
+		if totalRecent > 0 {
+			s.RecentShare = recent / totalRecent
+		}
+		if s.Dispatched > 0 {
+			s.AvgWaitMs = float64(s.totalWaitMs) / float64(s.Dispatched)
+		}
+		result.Flows[flow] = s
+
+		if s.Queued > 0 {
+			x := recent / float64(s.Weight)
+			sum += x
+			sumSquares += x * x
+			backlogged++
+		}
+	}
+	if backlogged > 0 && sumSquares > 0 {
+		result.Index = sum * sum / (float64(backlogged) * sumSquares)
+	}
+	return result
+}
+
+// decayed returns a count last updated at at, decayed to now
+func decayed(count float64, at, now time.Time) float64 {
+	if count == 0 {
+		return 0
+	}
+	return count * math.Exp(-now.Sub(at).Seconds()/fairnessDecay.Seconds())
+}
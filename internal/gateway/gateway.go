@@ -3,15 +3,25 @@ package gateway
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"modelgate/internal/cache/semantic"
 	"modelgate/internal/config"
+	"modelgate/internal/convbudget"
 	"modelgate/internal/domain"
+	"modelgate/internal/experiments"
+	"modelgate/internal/keybudget"
+	"modelgate/internal/killswitch"
 	"modelgate/internal/policy"
+	"modelgate/internal/pricing"
 	"modelgate/internal/provider"
 	"modelgate/internal/resilience"
 	"modelgate/internal/routing"
@@ -24,7 +34,7 @@ import (
 
 // Service is the main gateway service
 type Service struct {
-	config            *config.Config
+	config            atomic.Pointer[config.Config]
 	providers         *provider.Manager
 	policyEngine      domain.PolicyEngine
 	policyEnforcement *policy.EnforcementService
@@ -38,6 +48,16 @@ type Service struct {
 	healthTracker     *health.Tracker
 	resilienceService *resilience.Service
 	keySelector       *provider.KeySelector
+	pricing           *pricing.Service
+	throttler         *throttler
+	regions           *regionHealth
+	killSwitch        *killswitch.Service
+	keyBudgets        *keybudget.Service
+	convBudgets       *convbudget.Service
+	experiments       *experiments.Service
+
+	// Cache keys of streamed responses being stored
+	streamFills sync.Map
 }
 
 // NewService creates a new gateway service (backward compatible)
@@ -49,15 +69,18 @@ func NewService(
 	pgStore *postgres.Store,
 	metrics *telemetry.Metrics,
 ) *Service {
-	return &Service{
-		config:            cfg,
+	s := &Service{
 		providers:         providers,
 		policyEngine:      policyEngine,
 		policyEnforcement: policy.NewEnforcementService(),
 		usageRepo:         usageRepo,
 		pgStore:           pgStore,
 		metrics:           metrics,
+		throttler:         newThrottler(),
+		regions:           newRegionHealth(),
 	}
+	s.config.Store(cfg)
+	return s
 }
 
 // NewServiceWithFeatures creates a new gateway service with advanced features
@@ -74,8 +97,7 @@ func NewServiceWithFeatures(
 	resilienceService *resilience.Service,
 	keySelector *provider.KeySelector,
 ) *Service {
-	return &Service{
-		config:            cfg,
+	s := &Service{
 		providers:         providers,
 		policyEngine:      policyEngine,
 		policyEnforcement: policy.NewEnforcementService(),
@@ -87,7 +109,74 @@ func NewServiceWithFeatures(
 		healthTracker:     healthTracker,
 		resilienceService: resilienceService,
 		keySelector:       keySelector,
+		throttler:         newThrottler(),
+		regions:           newRegionHealth(),
 	}
+	s.config.Store(cfg)
+	return s
+}
+
+// SetConfig swaps in a reloaded configuration. Requests already running keep
+// the model and alias mappings they started with.
+func (s *Service) SetConfig(cfg *config.Config) {
+	s.config.Store(cfg)
+}
+
+// SetPricing sets the pricing service used to cost requests
+func (s *Service) SetPricing(svc *pricing.Service) {
+	s.pricing = svc
+}
+
+// SetKeyBudgets sets the API key budget service kept current with each request's cost
+func (s *Service) SetKeyBudgets(svc *keybudget.Service) {
+	s.keyBudgets = svc
+}
+
+// SetConversationBudgets enables the per-conversation ceilings of role budgets
+func (s *Service) SetConversationBudgets(svc *convbudget.Service) {
+	s.convBudgets = svc
+}
+
+// PriceFor returns the price that applies to a model now: an admin override,
+// then the model's pricing in config.toml, then provider and catalog prices.
+// It returns nil when the model's price is unknown.
+func (s *Service) PriceFor(ctx context.Context, model string) *domain.ModelPrice {
+	cfg := s.config.Load()
+	providerType, _ := cfg.GetProviderForModel(model)
+	price := s.pricing.Price(ctx, providerType, model, time.Now())
+	if price != nil && price.Source == domain.PriceSourceOverride {
+		return price
+	}
+	if modelCfg, ok := cfg.GetModel(model); ok && (modelCfg.InputCostPer1M > 0 || modelCfg.OutputCostPer1M > 0) {
+		return &domain.ModelPrice{
+			Provider:        providerType,
+			ModelID:         model,
+			InputCostPer1M:  modelCfg.InputCostPer1M,
+			OutputCostPer1M: modelCfg.OutputCostPer1M,
+			Source:          domain.PriceSourceConfig,
+		}
+	}
+	return price
+}
+
+// DryRunPolicy evaluates a hypothetical request against its role or group
+// policies and returns the full trace without executing anything
+func (s *Service) DryRunPolicy(ctx context.Context, store policy.DryRunStore, req *domain.ChatRequest) (*policy.DryRunResult, error) {
+	enforcement := s.policyEnforcement
+	if enforcement == nil {
+		enforcement = policy.NewEnforcementService()
+	}
+	return enforcement.DryRun(ctx, store, req)
+}
+
+// RateLimitStatus reports an API key's current rate limit windows under a
+// role policy without consuming from them. Returns nil if the policy sets no
+// rate limits.
+func (s *Service) RateLimitStatus(apiKeyID string, rolePolicy *domain.RolePolicy) *domain.RateLimitState {
+	if s.policyEnforcement == nil || rolePolicy == nil {
+		return nil
+	}
+	return s.policyEnforcement.RateLimitStatus("", apiKeyID, rolePolicy.RateLimitPolicy) // Single-tenant mode
 }
 
 // EnforcePolicy validates all policies before allowing an LLM operation
@@ -113,24 +202,56 @@ func (s *Service) EnforcePolicy(ctx context.Context, req *domain.ChatRequest, ro
 	// If there's a policy violation, record it to the database
 	if err != nil {
 		if policyViolation, ok := err.(*policy.PolicyViolation); ok {
-			// Map violation code to severity (1-5)
-			severity := s.getSeverityFromViolation(policyViolation)
-
-			// Record the violation
-			s.recordPolicyViolationEvent(
-				ctx,
-				"", // Single-tenant mode
-				req.APIKeyID,
-				"", // policyID - can be extracted from rolePolicy if needed
-				"", // policyName - can be extracted from rolePolicy if needed
-				policyViolation.Code,
-				severity,
-				policyViolation.Message,
-			)
+			s.recordViolation(ctx, req, policyViolation)
 		}
+		return err
 	}
 
-	return err
+	// Detections the role flags are recorded without refusing the request
+	for _, flag := range enfCtx.Flags {
+		s.recordViolation(ctx, req, flag)
+	}
+
+	// Expose the rate limit state for response headers and usage analytics
+	if enfCtx.RateLimit != nil {
+		req.RateLimit = enfCtx.RateLimit
+		if s.metrics != nil {
+			if enfCtx.RateLimit.BurstRequests > 0 {
+				s.metrics.RecordBurstCredits("requests", enfCtx.RateLimit.BurstRequests)
+			}
+			if enfCtx.RateLimit.BurstTokens > 0 {
+				s.metrics.RecordBurstCredits("tokens", enfCtx.RateLimit.BurstTokens)
+			}
+		}
+	}
+
+	return nil
+}
+
+// recordViolation records a policy violation or flag against the request's API key
+func (s *Service) recordViolation(ctx context.Context, req *domain.ChatRequest, violation *policy.PolicyViolation) {
+	message := violation.Message
+	if violation.Outlier != nil && len(violation.Outlier.OutlierReasons) > 0 {
+		message += ": " + strings.Join(violation.Outlier.OutlierReasons, "; ")
+	}
+	s.recordPolicyViolationEvent(
+		ctx,
+		"", // Single-tenant mode
+		req.APIKeyID,
+		"", // policyID - can be extracted from rolePolicy if needed
+		"", // policyName - can be extracted from rolePolicy if needed
+		violation.Code,
+		s.getSeverityFromViolation(violation), // Map violation code to severity (1-5)
+		message,
+	)
+}
+
+// SetInjectionMatcher enables injection detection by similarity to the
+// corpus of known attacks for roles with ML detection on
+func (s *Service) SetInjectionMatcher(m policy.InjectionMatcher) {
+	if s.policyEnforcement != nil {
+		s.policyEnforcement.SetInjectionMatcher(m)
+	}
 }
 
 // getSeverityFromViolation maps violation codes to severity levels (1-5)
@@ -138,8 +259,9 @@ func (s *Service) getSeverityFromViolation(violation *policy.PolicyViolation) in
 	// Map violation codes to severity levels
 	severityMap := map[string]int{
 		// Critical violations (5)
-		"injection_detected": 5,
-		"pii_detected":       5,
+		"injection_detected":          5,
+		"indirect_injection_detected": 5,
+		"pii_detected":                5,
 
 		// High violations (4)
 		"tool_blocked":      4,
@@ -147,10 +269,12 @@ func (s *Service) getSeverityFromViolation(violation *policy.PolicyViolation) in
 		"tools_not_allowed": 4,
 
 		// Medium violations (3)
-		"blocked_content":           3,
-		"rate_limit_exceeded":       3,
-		"token_rate_limit_exceeded": 3,
-		"tool_not_allowed":          3,
+		"blocked_content":            3,
+		"rate_limit_exceeded":        3,
+		"token_rate_limit_exceeded":  3,
+		"tool_not_allowed":           3,
+		"injection_flagged":          3,
+		"indirect_injection_flagged": 3,
 
 		// Low violations (2)
 		"too_many_tools":    2,
@@ -170,9 +294,21 @@ func (s *Service) getSeverityFromViolation(violation *policy.PolicyViolation) in
 // This loads provider configuration on-demand from the database per session
 // For single-tenant mode, use tenantSlug="default"
 func (s *Service) getClientForTenant(ctx context.Context, tenantID string, tenantSlug string, model string) (domain.LLMClient, error) {
-	providerType, ok := s.config.GetProviderForModel(model)
+	client, _, err := s.selectClient(ctx, tenantID, tenantSlug, model)
+	return client, err
+}
+
+// selectClient is getClientForTenant that also returns the ID of the provider
+// API key the client uses, so the outcome can be recorded against that key.
+// The ID is empty when the credentials didn't come from provider_api_keys.
+func (s *Service) selectClient(ctx context.Context, tenantID string, tenantSlug string, model string) (domain.LLMClient, string, error) {
+	providerType, ok := s.config.Load().GetProviderForModel(model)
 	if !ok {
-		return nil, fmt.Errorf("unknown provider for model: %s", model)
+		return nil, "", fmt.Errorf("unknown provider for model: %s", model)
+	}
+	// Also covers fallback and hedge targets
+	if perr := s.checkKillSwitch(providerType, model); perr != nil {
+		return nil, "", perr
 	}
 
 	// For single-tenant mode, use defaults
@@ -188,32 +324,33 @@ func (s *Service) getClientForTenant(ctx context.Context, tenantID string, tenan
 		tenantStore, err := s.pgStore.GetTenantStore(tenantSlug)
 		if err != nil {
 			slog.Error("Failed to get tenant store", "tenant_id", tenantID, "slug", tenantSlug, "error", err)
-			return nil, fmt.Errorf("failed to access tenant configuration")
+			return nil, "", fmt.Errorf("failed to access tenant configuration")
 		}
 
 		// Load provider config from database
 		providerCfg, err := tenantStore.GetProviderConfig(ctx, providerType)
 		if err != nil {
 			slog.Error("Failed to load provider config", "tenant_id", tenantID, "provider", providerType, "error", err)
-			return nil, fmt.Errorf("provider %s not configured for this tenant", providerType)
+			return nil, "", fmt.Errorf("provider %s not configured for this tenant", providerType)
 		}
 
 		if providerCfg == nil || !providerCfg.Enabled {
-			return nil, fmt.Errorf("provider %s is not enabled for this tenant", providerType)
+			return nil, "", fmt.Errorf("provider %s is not enabled for this tenant", providerType)
 		}
 
 		// Fetch API key from provider_api_keys table (multi-key support)
 		if s.keySelector != nil {
-			apiKey, err := s.keySelector.SelectKey(ctx, tenantSlug, providerType)
+			apiKey, err := s.keySelector.SelectKey(ctx, tenantSlug, providerType, providerCfg.KeyStrategy)
 			if err != nil {
 				slog.Debug("No API key found for provider", "provider", providerType, "error", err)
-				// For Ollama, API key is not required
-				if providerType != domain.ProviderOllama {
-					return nil, fmt.Errorf("no API key configured for provider %s", providerType)
+				// Self-hosted providers such as Ollama don't need an API key
+				if providerType.RequiresAPIKey() {
+					return nil, "", fmt.Errorf("no API key configured for provider %s", providerType)
 				}
 			} else if apiKey != nil {
 				// Populate credentials from the selected key
 				providerCfg.APIKey = apiKey.APIKeyDecrypted
+				providerCfg.APIKeyID = apiKey.ID
 				// For Bedrock, also populate IAM credentials if available
 				if providerType == domain.ProviderBedrock {
 					if apiKey.AccessKeyIDDecrypted != "" {
@@ -226,7 +363,11 @@ func (s *Service) getClientForTenant(ctx context.Context, tenantID string, tenan
 				slog.Debug("Selected API key for provider",
 					"provider", providerType,
 					"key_name", apiKey.Name,
-					"key_prefix", apiKey.KeyPrefix)
+					"key_prefix", apiKey.KeyPrefix,
+					"selection", apiKey.Selection)
+				if s.metrics != nil {
+					s.metrics.RecordKeySelection(string(providerType), model, apiKey.ID, apiKey.Selection)
+				}
 			}
 		}
 
@@ -270,7 +411,7 @@ func (s *Service) getClientForTenant(ctx context.Context, tenantID string, tenan
 					"requested_model", model,
 					"model_to_check", modelToCheck,
 					"available_count", len(availableModels))
-				return nil, fmt.Errorf("model %s is not enabled for this tenant", model)
+				return nil, "", fmt.Errorf("model %s is not enabled for this tenant", model)
 			}
 		}
 
@@ -281,10 +422,90 @@ func (s *Service) getClientForTenant(ctx context.Context, tenantID string, tenan
 
 		// Create or get cached tenant-specific client
 		// The client will automatically receive the model cache from the cache service
-		return s.providers.GetOrCreateTenantClient(tenantID, providerType, providerCfg)
+		// Multi-region providers fail over between their regional deployments
+		if len(enabledRegions(providerCfg)) > 0 {
+			regional, err := s.regionalClients(ctx, tenantID, tenantSlug, providerCfg)
+			if err != nil {
+				return nil, "", err
+			}
+			if regional != nil {
+				return s.throttler.throttle(regional, providerCfg.Throttles), providerCfg.APIKeyID, nil
+			}
+		}
+
+		client, err := s.providers.GetOrCreateTenantClient(tenantID, providerType, providerCfg)
+		if err != nil {
+			return nil, "", err
+		}
+		return s.throttler.throttle(client, providerCfg.Throttles), providerCfg.APIKeyID, nil
+	}
+
+	return nil, "", fmt.Errorf("tenant configuration not available")
+}
+
+// withQuotaObserver returns a context that routes the rate limit headers of the
+// provider's responses to the health tracker, where the key selector reads them,
+// and to Prometheus
+func (s *Service) withQuotaObserver(ctx context.Context, providerType domain.Provider, keyID string) context.Context {
+	if s.healthTracker == nil {
+		return ctx
 	}
+	return provider.WithQuotaObserver(ctx, func(q domain.ProviderQuota) {
+		s.healthTracker.RecordQuota(string(providerType), keyID, q)
+		if s.metrics != nil {
+			s.metrics.UpdateProviderQuota(string(providerType), keyID, q)
+		}
+	})
+}
 
-	return nil, fmt.Errorf("tenant configuration not available")
+// ProviderQuotas returns the upstream rate limit state last reported for each provider key
+func (s *Service) ProviderQuotas() []health.KeyQuota {
+	if s.healthTracker == nil {
+		return nil
+	}
+	return s.healthTracker.Quotas()
+}
+
+// errStreamFailed is recorded against a provider key when a stream ends in an error
+var errStreamFailed = errors.New("stream ended with an error")
+
+// recordKeyOutcome reports a request's result against the provider API key
+// that served it, feeding key health and canary rollback
+func (s *Service) recordKeyOutcome(ctx context.Context, keyID string, err error) {
+	if s.keySelector == nil || keyID == "" || errors.Is(err, errThrottled) {
+		return
+	}
+	go s.keySelector.RecordOutcome(context.WithoutCancel(ctx), "default", keyID, err)
+}
+
+// recordKeyUsage adds a request's tokens and cost to the provider API key
+// that served it
+func (s *Service) recordKeyUsage(ctx context.Context, keyID string, inputTokens, outputTokens int64, costUSD float64) {
+	if s.keySelector == nil || keyID == "" {
+		return
+	}
+	go s.keySelector.RecordUsage(context.WithoutCancel(ctx), "default", keyID, inputTokens, outputTokens, costUSD)
+}
+
+type tenantKey struct{}
+
+// WithTenant returns a context in which GetClientForModel resolves the
+// provider clients of the given tenant
+func WithTenant(ctx context.Context, tenantSlug string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantSlug)
+}
+
+// GetClientForModel returns the provider client for a model, configured for
+// the tenant set with WithTenant, or the default tenant without one. Used by
+// services that talk to providers directly, such as native batches, so the
+// client isn't throttled.
+func (s *Service) GetClientForModel(ctx context.Context, model string) (domain.LLMClient, error) {
+	tenantSlug, _ := ctx.Value(tenantKey{}).(string)
+	client, err := s.getClientForTenant(ctx, "", tenantSlug, model)
+	if err != nil {
+		return nil, err
+	}
+	return unthrottled(client), nil
 }
 
 // LoadModelCacheForTenant loads the model cache for all providers for a tenant
@@ -327,11 +548,12 @@ func (s *Service) ChatStream(ctx context.Context, req *domain.ChatRequest) (<-ch
 	}
 
 	// Resolve model alias
-	req.Model = s.config.ResolveModel(req.Model)
+	req.Model = s.config.Load().ResolveModel(req.Model)
+	s.assignExperiment(req)
 	originalModel := req.Model
 
 	// Get provider
-	providerType, ok := s.config.GetProviderForModel(req.Model)
+	providerType, ok := s.config.Load().GetProviderForModel(req.Model)
 	if !ok {
 		return nil, fmt.Errorf("unknown provider for model: %s", req.Model)
 	}
@@ -345,39 +567,60 @@ func (s *Service) ChatStream(ctx context.Context, req *domain.ChatRequest) (<-ch
 	// Get role policy for advanced features
 	rolePolicy := s.getRolePolicy(ctx, req.RoleID)
 
+	// The deadline covers the whole stream, so it is cancelled only once the
+	// stream ends or the request fails before streaming
+	ctx, cancelDeadline := applyRequestLimits(ctx, req, rolePolicy)
+	streaming := false
+	defer func() {
+		if !streaming {
+			cancelDeadline()
+		}
+	}()
+
+	// =========================================================================
+	// 0. CONVERSATION BUDGET - Refuse turns of conversations past their ceiling
+	// =========================================================================
+	if perr := s.checkConversationBudget(ctx, req, rolePolicy, providerType); perr != nil {
+		if recorder != nil {
+			recorder.RecordError(perr.Code)
+		}
+		return nil, perr
+	}
+
 	// =========================================================================
 	// 1. SEMANTIC CACHE - Check for cached response
 	// =========================================================================
-	if s.isCacheEnabled(rolePolicy) && rolePolicy.CachingPolicy.CacheStreaming {
-		cachedResponse, hit, err := s.semanticCache.Get(
+	if s.cacheLookupEnabled(rolePolicy, req) && rolePolicy.CachingPolicy.CacheStreaming {
+		cached, err := s.semanticCache.GetWithDetails(
 			ctx, req.RoleID, req.Model, req.Messages, rolePolicy.CachingPolicy,
 		)
 		if err != nil {
 			slog.Warn("Semantic cache lookup failed (streaming)", "error", err, "request_id", req.RequestID)
-		} else if hit {
+		} else if cached.Hit {
+			cachedResponse := cached.Response
 			slog.Info("Semantic cache hit (streaming)",
 				"request_id", req.RequestID,
 				"model", req.Model)
 
 			// Record cache hit metrics and persist to database
-			if cachedResponse.Usage != nil {
-				tokensSaved := int64(cachedResponse.Usage.PromptTokens + cachedResponse.Usage.CompletionTokens)
-				s.recordCacheHitEvent(ctx, "", req.APIKeyID, req.Model, tokensSaved, cachedResponse.CostUSD)
-			}
+			req.CacheLookup = cacheHitLookup(cached)
+			s.recordCacheHitEvent(ctx, "", req, string(providerType), cached)
 
 			// Convert cached response to stream events
+			cachedResponse = transformResponse(req, rolePolicy, cachedResponse)
 			return s.convertResponseToStream(cachedResponse, recorder, startTime), nil
 		}
 
 		// Record cache miss and persist to database
-		s.recordCacheMissEvent(ctx, "", req.APIKeyID, req.Model)
+		req.CacheLookup = &domain.CacheLookup{Status: domain.CacheStatusMiss}
+		s.recordCacheMissEvent(ctx, "", req, string(providerType))
 	}
 
 	// =========================================================================
 	// 2. INTELLIGENT ROUTING - Select optimal provider/model
 	// =========================================================================
-	if s.isRoutingEnabled(rolePolicy) {
-		routedProvider, routedModel, err := s.router.Route(ctx, req, rolePolicy.RoutingPolicy)
+	if s.isRoutingEnabled(rolePolicy) && !req.Experiment.PinsModel() {
+		routedProvider, routedModel, err := s.router.Route(ctx, req, rolePolicy.RoutingPolicy, rolePolicy.ModelRestriction)
 		if err != nil {
 			slog.Warn("Routing failed (streaming), using original model",
 				"error", err,
@@ -391,7 +634,7 @@ func (s *Service) ChatStream(ctx context.Context, req *domain.ChatRequest) (<-ch
 			newModel := routedProvider + "/" + routedModel
 			// Record routing decision
 			if s.metrics != nil {
-				s.metrics.RecordRoutingDecision(string(rolePolicy.RoutingPolicy.Strategy), "")
+				s.metrics.RecordRoutingDecision(string(rolePolicy.RoutingPolicy.Strategy), newModel, routedProvider, req.RoleID)
 			}
 			if newModel != req.Model {
 				slog.Info("Routing selected different model (streaming)",
@@ -405,13 +648,56 @@ func (s *Service) ChatStream(ctx context.Context, req *domain.ChatRequest) (<-ch
 				}
 				req.Model = newModel
 				// Update provider type for the new model
-				if newProviderType, ok := s.config.GetProviderForModel(req.Model); ok {
+				if newProviderType, ok := s.config.Load().GetProviderForModel(req.Model); ok {
 					providerType = newProviderType
 				}
 			}
 		}
 	}
 
+	// =========================================================================
+	// 3. CONTEXT WINDOW - Truncate, summarize or reroute oversized requests
+	// =========================================================================
+	if s.manageContext(ctx, req, rolePolicy) {
+		if newProviderType, ok := s.config.Load().GetProviderForModel(req.Model); ok {
+			providerType = newProviderType
+		}
+	}
+
+	// =========================================================================
+	// 3b. CAPABILITIES - Refuse or reroute requests the model can't accept
+	// =========================================================================
+	if switched, perr := s.checkCapabilities(ctx, req, rolePolicy); perr != nil {
+		if recorder != nil {
+			recorder.RecordError(perr.Code)
+		}
+		return nil, perr
+	} else if switched {
+		if newProviderType, ok := s.config.Load().GetProviderForModel(req.Model); ok {
+			providerType = newProviderType
+		}
+	}
+
+	// =========================================================================
+	// 3c. COST LIMIT - Refuse requests projected to cost more than their cap
+	// =========================================================================
+	if perr := s.checkCostLimit(ctx, req); perr != nil {
+		if recorder != nil {
+			recorder.RecordError(perr.Code)
+		}
+		return nil, perr
+	}
+
+	// =========================================================================
+	// 3d. KILL SWITCH - Refuse models an admin switched off
+	// =========================================================================
+	if perr := s.checkKillSwitch(providerType, req.Model); perr != nil {
+		if recorder != nil {
+			recorder.RecordError(perr.Code)
+		}
+		return nil, perr
+	}
+
 	// NOTE: For streaming, we don't do explicit circuit breaker checks or fallbacks
 	// Health tracking will inform routing decisions for subsequent requests
 	// Policy enforcement is now done at the HTTP layer BEFORE reaching gateway
@@ -420,7 +706,7 @@ func (s *Service) ChatStream(ctx context.Context, req *domain.ChatRequest) (<-ch
 	// =========================================================================
 	// 4. GET CLIENT - Load provider client
 	// =========================================================================
-	client, err := s.getClientForTenant(ctx, "", "default", req.Model)
+	client, providerKeyID, err := s.selectClient(ctx, "", "default", req.Model)
 	if err != nil {
 		if recorder != nil {
 			recorder.RecordError("provider_error")
@@ -440,37 +726,140 @@ func (s *Service) ChatStream(ctx context.Context, req *domain.ChatRequest) (<-ch
 		"tool_count", len(req.Tools),
 		"request_id", req.RequestID,
 	)
-	events, err := client.ChatStream(ctx, req)
+	// Output moderation can end the stream early, so the upstream call gets its own cancel
+	var moderator *policy.StreamModerator
+	if rolePolicy != nil {
+		moderator = policy.NewStreamModerator(&rolePolicy.PromptPolicies, systemPromptText(req))
+	}
+	// Output transformation rewrites the text after moderation has scanned it
+	transformer := newOutputTransformer(rolePolicy)
+	// Providers without native structured output get the format as instructions;
+	// a stream can't be retried, so its output isn't validated. Documents go
+	// into the prompt likewise for providers that can't take them.
+	upstreamReq := req
+	if emulatesDocuments(client, upstreamReq) {
+		upstreamReq = withDocumentsPrompt(upstreamReq)
+	}
+	if emulatesResponseFormat(client, upstreamReq) {
+		upstreamReq = withResponseFormatPrompt(upstreamReq)
+	}
+	// Latency-sensitive roles may hedge a slow start to a second provider
+	leg, err := s.openStream(ctx, req, rolePolicy, &streamLeg{
+		client:   client,
+		provider: providerType,
+		model:    req.Model,
+		keyID:    providerKeyID,
+		req:      upstreamReq,
+	})
 	if err != nil {
+		s.recordKeyOutcome(ctx, leg.keyID, err)
+		s.releaseAffinity(req)
+		providerErr := provider.NormalizeError(leg.provider, err)
 		if recorder != nil {
-			recorder.RecordError("stream_error")
+			recorder.RecordError(providerErr.Code)
 		}
 		// Record failure in health tracker
 		if s.healthTracker != nil {
-			s.healthTracker.RecordFailure(ctx, "", string(providerType), req.Model, "stream_error")
+			s.healthTracker.RecordFailure(ctx, "", string(leg.provider), leg.model, providerErr.Code)
 		}
-		return nil, err
+		if s.usageRepo != nil {
+			s.recordUsage(ctx, req, "", 0, 0, 0, time.Since(startTime), false, providerErr.Code)
+		}
+		return nil, providerErr
+	}
+	if leg.name == hedgeLegHedge {
+		req.Model, providerType, providerKeyID = leg.model, leg.provider, leg.keyID
+	}
+	events, stopUpstream := leg.stream(), leg.cancel
+	if len(req.Documents) > 0 && len(leg.req.Documents) == 0 {
+		// The documents went into the prompt; cite them from the answer's markers
+		events = citeDocuments(events, req.Documents)
 	}
 
 	// =========================================================================
 	// 6. WRAP EVENTS - Buffer response, track metrics, cache on completion
 	// =========================================================================
 	wrappedEvents := make(chan domain.StreamEvent, 100)
+	streaming = true
 	go func() {
 		defer close(wrappedEvents)
+		defer cancelDeadline()
+		defer stopUpstream()
+		// Stop the provider as soon as the client disconnects or the deadline
+		// passes, rather than when its next chunk arrives
+		defer context.AfterFunc(ctx, stopUpstream)()
 
 		var inputTokens, outputTokens int64
 		var costUSD float64
 
+		// Set once output moderation cut the stream off; remaining upstream events are drained
+		var truncated bool
+		var sentChars int
+
+		// Set when the provider reported why the stream failed
+		var streamErr *domain.ProviderError
+		var finished bool
+
+		// Response to cache, assembled when the stream finished cleanly and
+		// stored once the provider closed it
+		var cacheable *domain.ChatResponse
+
 		// Buffer response for caching (if enabled)
 		var bufferedContent strings.Builder
 		var toolCalls []domain.ToolCall
-		shouldCache := s.isCacheEnabled(rolePolicy) && rolePolicy.CachingPolicy.CacheStreaming
+		shouldCache := s.cacheStoreEnabled(rolePolicy, req) && rolePolicy.CachingPolicy.CacheStreaming
+		// Also buffer when the response text is captured for replay
+		shouldBuffer := shouldCache || s.config.Load().Replay.CaptureContent
 
 		for event := range events {
+			if truncated {
+				if usage, ok := event.(domain.UsageEvent); ok {
+					inputTokens = int64(usage.PromptTokens)
+					outputTokens = int64(usage.CompletionTokens)
+				}
+				continue
+			}
+
+			// =========================================================================
+			// OUTPUT MODERATION - Scan a sliding window before text reaches the client
+			// =========================================================================
+			if moderator != nil {
+				if textChunk, ok := event.(domain.TextChunk); ok {
+					text, violation := moderator.Push(textChunk.Content)
+					if violation != nil {
+						s.handleOutputViolation(ctx, req, violation)
+					}
+					if violation != nil && violation.Truncated {
+						truncated = true
+						stopUpstream()
+						wrappedEvents <- domain.FinishEvent{Reason: domain.FinishReasonPolicyViolation}
+						continue
+					}
+					if text == "" {
+						continue
+					}
+					textChunk.Content = text
+					event = textChunk
+				} else if text := moderator.Flush(); text != "" {
+					sentChars += len(text)
+					if shouldBuffer {
+						bufferedContent.WriteString(text)
+					}
+					if transformer != nil {
+						text = transformer.Push(text)
+					}
+					if text != "" {
+						wrappedEvents <- domain.TextChunk{Content: text}
+					}
+				}
+			}
+
 			// Buffer text chunks for caching
-			if textChunk, ok := event.(domain.TextChunk); ok && shouldCache {
-				bufferedContent.WriteString(textChunk.Content)
+			if textChunk, ok := event.(domain.TextChunk); ok {
+				sentChars += len(textChunk.Content)
+				if shouldBuffer {
+					bufferedContent.WriteString(textChunk.Content)
+				}
 			}
 
 			// Buffer tool call events
@@ -480,22 +869,68 @@ func (s *Service) ChatStream(ctx context.Context, req *domain.ChatRequest) (<-ch
 				s.recordToolCallEvent(ctx, "", req.APIKeyID, toolCallEvent.ToolCall.Function.Name, req.Model, string(providerType), true, "")
 			}
 
+			if errEvent, ok := event.(domain.ErrorEvent); ok && errEvent.Err != nil {
+				streamErr = errEvent.Err
+			}
+
 			// Track metrics from usage events
 			if usage, ok := event.(domain.UsageEvent); ok {
 				inputTokens = int64(usage.PromptTokens)
 				outputTokens = int64(usage.CompletionTokens)
+				req.CachedPromptTokens = usage.CachedPromptTokens
 
 				slog.Info("Received UsageEvent (streaming)",
 					"model", req.Model,
 					"input_tokens", inputTokens,
 					"output_tokens", outputTokens,
+					"cached_input_tokens", usage.CachedPromptTokens,
 					"request_id", req.RequestID)
 
 				// Calculate cost
-				if modelCfg, ok := s.config.GetModel(req.Model); ok {
-					costUSD = modelCfg.CalculateCost(inputTokens, outputTokens)
+				if price := s.PriceFor(ctx, req.Model); price != nil {
+					costUSD = price.CostWithCache(inputTokens, int64(usage.CachedPromptTokens), outputTokens)
 					usage.CostUSD = costUSD
 					event = usage
+				} else if usage.CostUSD > 0 {
+					// Providers billed by time (Hugging Face endpoints) cost it themselves
+					costUSD = usage.CostUSD
+				}
+			}
+
+			// =========================================================================
+			// OUTPUT TRANSFORM - Rewrite the text the client receives; the
+			// buffered response stays as generated
+			// =========================================================================
+			if transformer != nil {
+				switch e := event.(type) {
+				case domain.TextChunk:
+					if e.Content = transformer.Push(e.Content); e.Content == "" {
+						continue
+					}
+					event = e
+				case domain.ThinkingChunk:
+					if transformer.DropsThinking() {
+						continue
+					}
+				case domain.CitationEvent:
+					if transformer.DropsCitations() {
+						continue
+					}
+				default:
+					if text := transformer.Flush(); text != "" {
+						wrappedEvents <- domain.TextChunk{Content: text}
+					}
+					if _, ok := event.(domain.FinishEvent); ok {
+						req.OutputTransform = transformer.Result()
+					}
+				}
+			}
+
+			// Time to first token: the first text, reasoning or tool call the client receives
+			if req.StreamTiming == nil {
+				switch event.(type) {
+				case domain.TextChunk, domain.ThinkingChunk, domain.ToolCallEvent:
+					req.StreamTiming = &domain.StreamTiming{TimeToFirstToken: time.Since(startTime)}
 				}
 			}
 
@@ -504,6 +939,7 @@ func (s *Service) ChatStream(ctx context.Context, req *domain.ChatRequest) (<-ch
 
 			// Handle finish event - cache, track health, record usage
 			if finish, ok := event.(domain.FinishEvent); ok {
+				finished = true
 				latencyMs := time.Since(startTime).Milliseconds()
 
 				slog.Info("Received FinishEvent (streaming)",
@@ -516,10 +952,16 @@ func (s *Service) ChatStream(ctx context.Context, req *domain.ChatRequest) (<-ch
 					"reason", finish.Reason)
 
 				success := finish.Reason == domain.FinishReasonStop || finish.Reason == domain.FinishReasonToolCalls
+				if success && req.StreamTiming != nil {
+					req.StreamTiming.Finish(startTime, time.Now(), outputTokens)
+				}
 
 				if success {
 					if recorder != nil {
 						recorder.RecordSuccess(inputTokens, outputTokens, costUSD)
+						if req.StreamTiming != nil {
+							recorder.RecordStreamTiming(req.StreamTiming.TimeToFirstToken, req.StreamTiming.TokensPerSecond)
+						}
 					}
 
 					// Record all accumulated tool calls to database
@@ -528,10 +970,11 @@ func (s *Service) ChatStream(ctx context.Context, req *domain.ChatRequest) (<-ch
 					}
 
 					// =========================================================================
-					// 7. SEMANTIC CACHE - Store buffered response
+					// 7. SEMANTIC CACHE - Assemble the buffered response
 					// =========================================================================
 					// Don't cache responses with tool_calls or responses from conversations with tool results
-					// Tool results are time-dependent (e.g., get_datetime, read_file, search_web)
+					// Tool results are time-dependent (e.g., get_datetime, read_file, search_web).
+					// Nor streams that reported an error, were cut off or ran out of tokens.
 					hasToolMessages := false
 					for _, msg := range req.Messages {
 						if msg.Role == "tool" {
@@ -539,69 +982,127 @@ func (s *Service) ChatStream(ctx context.Context, req *domain.ChatRequest) (<-ch
 							break
 						}
 					}
-					if shouldCache && bufferedContent.Len() > 0 && finish.Reason != domain.FinishReasonToolCalls && !hasToolMessages {
-						go func() {
-							// Construct response from buffered data
-							bufferedResponse := &domain.ChatResponse{
-								Content:      bufferedContent.String(),
-								ToolCalls:    toolCalls,
-								Model:        originalModel,
-								FinishReason: finish.Reason,
-								Usage: &domain.UsageEvent{
-									PromptTokens:     int32(inputTokens),
-									CompletionTokens: int32(outputTokens),
-									TotalTokens:      int32(inputTokens + outputTokens),
-									CostUSD:          costUSD,
-								},
-								CostUSD:   costUSD,
-								LatencyMs: latencyMs,
-								Provider:  providerType,
-								Cached:    false,
-							}
-
-							cacheErr := s.semanticCache.Set(
-								context.Background(),
-								req.RoleID, originalModel, string(providerType),
-								req.Messages, bufferedResponse,
-								rolePolicy.CachingPolicy,
-							)
-							if cacheErr != nil {
-								slog.Warn("Failed to cache streaming response", "error", cacheErr, "request_id", req.RequestID)
-							} else {
-								slog.Debug("Cached streaming response", "request_id", req.RequestID, "content_length", bufferedContent.Len())
-							}
-						}()
+					if shouldCache && finish.Reason == domain.FinishReasonStop && streamErr == nil && ctx.Err() == nil &&
+						bufferedContent.Len() > 0 && !hasToolMessages && req.OutputViolation == nil {
+						cacheable = &domain.ChatResponse{
+							Content:      bufferedContent.String(),
+							ToolCalls:    slices.Clone(toolCalls),
+							Model:        originalModel,
+							FinishReason: finish.Reason,
+							Usage: &domain.UsageEvent{
+								PromptTokens:     int32(inputTokens),
+								CompletionTokens: int32(outputTokens),
+								TotalTokens:      int32(inputTokens + outputTokens),
+								CostUSD:          costUSD,
+							},
+							CostUSD:   costUSD,
+							LatencyMs: latencyMs,
+							Provider:  providerType,
+						}
 					}
 
 					// =========================================================================
 					// 8. HEALTH TRACKING - Record success
 					// =========================================================================
 					if s.healthTracker != nil {
-						s.healthTracker.RecordSuccess(ctx, "", string(providerType), req.Model, int(latencyMs))
+						if timing := req.StreamTiming; timing != nil {
+							s.healthTracker.RecordStreamSuccess(ctx, "", string(providerType), req.Model, int(latencyMs),
+								int(timing.TimeToFirstToken.Milliseconds()), timing.TokensPerSecond)
+						} else {
+							s.healthTracker.RecordSuccess(ctx, "", string(providerType), req.Model, int(latencyMs))
+						}
 					}
+					s.recordKeyOutcome(ctx, providerKeyID, nil)
+					s.recordKeyUsage(ctx, providerKeyID, int64(inputTokens), int64(outputTokens), costUSD)
 
 					// =========================================================================
 					// 9. USAGE TRACKING - Record API usage
 					// =========================================================================
 					if s.usageRepo != nil {
-						s.recordUsage(ctx, req, inputTokens, outputTokens, costUSD, time.Since(startTime), true, "")
+						s.recordUsage(ctx, req, bufferedContent.String(), inputTokens, outputTokens, costUSD, time.Since(startTime), true, "")
 					}
 				} else if finish.Reason == domain.FinishReasonError {
+					if ctx.Err() != nil {
+						// Cut off by the client or the deadline, whatever the provider reported
+						streamErr = provider.NormalizeError(providerType, ctx.Err())
+					} else if streamErr == nil {
+						streamErr = provider.NormalizeError(providerType, errStreamFailed)
+					}
 					if recorder != nil {
-						recorder.RecordError("stream_error")
+						recorder.RecordError(streamErr.Code)
 					}
 
-					// Record failure in health tracker
-					if s.healthTracker != nil {
-						s.healthTracker.RecordFailure(ctx, "", string(providerType), req.Model, "stream_error")
+					// A client disconnect says nothing about the provider's health
+					if streamErr.Code != domain.ErrorCodeCancelled {
+						if s.healthTracker != nil {
+							s.healthTracker.RecordFailure(ctx, "", string(providerType), req.Model, streamErr.Code)
+						}
+						s.recordKeyOutcome(ctx, providerKeyID, streamErr)
 					}
+					s.releaseAffinity(req)
 
 					if s.usageRepo != nil {
-						s.recordUsage(ctx, req, inputTokens, outputTokens, costUSD, time.Since(startTime), false, "stream_error")
+						s.recordUsage(context.WithoutCancel(ctx), req, bufferedContent.String(), inputTokens, outputTokens, costUSD, time.Since(startTime), false, streamErr.Code)
 					}
 				}
 			}
 		}
+
+		// An error reported after the finish event means the stream can't be trusted
+		if cacheable != nil && streamErr == nil && !truncated {
+			s.cacheStreamedResponse(req, originalModel, providerType, cacheable, rolePolicy.CachingPolicy)
+		}
+
+		// Release held-back text if the provider closed the stream without a finish event
+		if moderator != nil && !truncated {
+			if text := moderator.Flush(); text != "" {
+				if transformer != nil {
+					text = transformer.Push(text)
+				}
+				if text != "" {
+					wrappedEvents <- domain.TextChunk{Content: text}
+				}
+			}
+		}
+		if transformer != nil && !truncated {
+			if text := transformer.Flush(); text != "" {
+				wrappedEvents <- domain.TextChunk{Content: text}
+			}
+		}
+
+		if !finished && !truncated && ctx.Err() != nil {
+			// The provider closed the stream without a finish event once it was cancelled
+			code := provider.NormalizeError(providerType, ctx.Err()).Code
+			if outputTokens == 0 {
+				outputTokens = int64(sentChars / 4)
+			}
+			if price := s.PriceFor(context.WithoutCancel(ctx), req.Model); price != nil {
+				costUSD = price.Cost(inputTokens, outputTokens)
+			}
+			if recorder != nil {
+				recorder.RecordError(code)
+			}
+			s.releaseAffinity(req)
+			if s.usageRepo != nil {
+				s.recordUsage(context.WithoutCancel(ctx), req, bufferedContent.String(), inputTokens, outputTokens, costUSD, time.Since(startTime), false, code)
+			}
+		}
+
+		if truncated {
+			// The provider may not report usage for a cancelled stream
+			if outputTokens == 0 {
+				outputTokens = int64(sentChars / 4)
+			}
+			if price := s.PriceFor(ctx, req.Model); price != nil {
+				costUSD = price.Cost(inputTokens, outputTokens)
+			}
+			if recorder != nil {
+				recorder.RecordError("output_policy_violation")
+			}
+			if s.usageRepo != nil {
+				s.recordUsage(ctx, req, bufferedContent.String(), inputTokens, outputTokens, costUSD, time.Since(startTime), false, "output_policy_violation")
+			}
+		}
 	}()
 
 	return wrappedEvents, nil
@@ -648,6 +1149,22 @@ func (s *Service) convertResponseToStream(response *domain.ChatResponse, recorde
 	return events
 }
 
+// fallbackTarget returns the model a fallback chain entry sends a request
+// to. An OpenRouter entry without a model serves the requested model through
+// OpenRouter, so direct providers can fail over to it.
+func fallbackTarget(primary domain.Provider, model, fallbackProvider, fallbackModel string) (string, error) {
+	if fallbackModel != "" {
+		return fallbackProvider + "/" + fallbackModel, nil
+	}
+	if domain.Provider(fallbackProvider) == domain.ProviderOpenRouter {
+		if target, ok := provider.OpenRouterModel(primary, model); ok {
+			return target, nil
+		}
+		return "", fmt.Errorf("OpenRouter doesn't serve %s models under their own names; set the fallback's model", primary)
+	}
+	return "", fmt.Errorf("fallback to %s has no model", fallbackProvider)
+}
+
 // ChatComplete handles non-streaming chat completion
 // Integrates: semantic caching, intelligent routing, resilience, and health tracking
 func (s *Service) ChatComplete(ctx context.Context, req *domain.ChatRequest) (*domain.ChatResponse, error) {
@@ -657,10 +1174,11 @@ func (s *Service) ChatComplete(ctx context.Context, req *domain.ChatRequest) (*d
 		req.RequestID = uuid.New().String()
 	}
 
-	req.Model = s.config.ResolveModel(req.Model)
+	req.Model = s.config.Load().ResolveModel(req.Model)
+	s.assignExperiment(req)
 	originalModel := req.Model
 
-	providerType, ok := s.config.GetProviderForModel(req.Model)
+	providerType, ok := s.config.Load().GetProviderForModel(req.Model)
 	if !ok {
 		return nil, fmt.Errorf("unknown provider for model: %s", req.Model)
 	}
@@ -673,16 +1191,31 @@ func (s *Service) ChatComplete(ctx context.Context, req *domain.ChatRequest) (*d
 	// Get role policy for advanced features
 	rolePolicy := s.getRolePolicy(ctx, req.RoleID)
 
+	ctx, cancelDeadline := applyRequestLimits(ctx, req, rolePolicy)
+	defer cancelDeadline()
+
+	// =========================================================================
+	// 0. CONVERSATION BUDGET - Refuse turns of conversations past their ceiling
+	// =========================================================================
+	if perr := s.checkConversationBudget(ctx, req, rolePolicy, providerType); perr != nil {
+		if recorder != nil {
+			recorder.RecordError(perr.Code)
+		}
+		return nil, perr
+	}
+
 	// =========================================================================
 	// 1. SEMANTIC CACHE - Check for cached response
 	// =========================================================================
-	if s.isCacheEnabled(rolePolicy) {
-		cachedResponse, hit, err := s.semanticCache.Get(
+	// Replays always reach the provider and keep the replayed model
+	if s.cacheLookupEnabled(rolePolicy, req) && req.ReplayOf == "" {
+		cached, err := s.semanticCache.GetWithDetails(
 			ctx, req.RoleID, req.Model, req.Messages, rolePolicy.CachingPolicy,
 		)
 		if err != nil {
 			slog.Warn("Semantic cache lookup failed", "error", err, "request_id", req.RequestID)
-		} else if hit {
+		} else if cached.Hit {
+			cachedResponse := cached.Response
 			slog.Info("Semantic cache hit",
 				"request_id", req.RequestID,
 				"model", req.Model,
@@ -693,26 +1226,25 @@ func (s *Service) ChatComplete(ctx context.Context, req *domain.ChatRequest) (*d
 			cachedResponse.LatencyMs = time.Since(startTime).Milliseconds()
 
 			// Record cache hit metrics and persist to database
-			if cachedResponse.Usage != nil {
-				tokensSaved := int64(cachedResponse.Usage.PromptTokens + cachedResponse.Usage.CompletionTokens)
-				s.recordCacheHitEvent(ctx, "", req.APIKeyID, req.Model, tokensSaved, cachedResponse.CostUSD)
-			}
+			req.CacheLookup = cacheHitLookup(cached)
+			s.recordCacheHitEvent(ctx, "", req, string(providerType), cached)
 
 			if recorder != nil {
 				recorder.RecordSuccess(0, 0, 0) // Cache hit - no tokens consumed
 			}
-			return cachedResponse, nil
+			return transformResponse(req, rolePolicy, cachedResponse), nil
 		}
 
 		// Record cache miss and persist to database
-		s.recordCacheMissEvent(ctx, "", req.APIKeyID, req.Model)
+		req.CacheLookup = &domain.CacheLookup{Status: domain.CacheStatusMiss}
+		s.recordCacheMissEvent(ctx, "", req, string(providerType))
 	}
 
 	// =========================================================================
 	// 2. INTELLIGENT ROUTING - Select optimal provider/model
 	// =========================================================================
-	if s.isRoutingEnabled(rolePolicy) {
-		routedProvider, routedModel, err := s.router.Route(ctx, req, rolePolicy.RoutingPolicy)
+	if s.isRoutingEnabled(rolePolicy) && req.ReplayOf == "" && !req.Experiment.PinsModel() {
+		routedProvider, routedModel, err := s.router.Route(ctx, req, rolePolicy.RoutingPolicy, rolePolicy.ModelRestriction)
 		if err != nil {
 			slog.Warn("Routing failed, using original model",
 				"error", err,
@@ -726,7 +1258,7 @@ func (s *Service) ChatComplete(ctx context.Context, req *domain.ChatRequest) (*d
 			newModel := routedProvider + "/" + routedModel
 			// Record routing decision
 			if s.metrics != nil {
-				s.metrics.RecordRoutingDecision(string(rolePolicy.RoutingPolicy.Strategy), "")
+				s.metrics.RecordRoutingDecision(string(rolePolicy.RoutingPolicy.Strategy), newModel, routedProvider, req.RoleID)
 			}
 			if newModel != req.Model {
 				slog.Info("Routing selected different model",
@@ -740,17 +1272,60 @@ func (s *Service) ChatComplete(ctx context.Context, req *domain.ChatRequest) (*d
 				}
 				req.Model = newModel
 				// Update provider type for the new model
-				if newProviderType, ok := s.config.GetProviderForModel(req.Model); ok {
+				if newProviderType, ok := s.config.Load().GetProviderForModel(req.Model); ok {
 					providerType = newProviderType
 				}
 			}
 		}
 	}
 
+	// =========================================================================
+	// 2b. CONTEXT WINDOW - Truncate, summarize or reroute oversized requests
+	// =========================================================================
+	if s.manageContext(ctx, req, rolePolicy) {
+		if newProviderType, ok := s.config.Load().GetProviderForModel(req.Model); ok {
+			providerType = newProviderType
+		}
+	}
+
+	// =========================================================================
+	// 2c. CAPABILITIES - Refuse or reroute requests the model can't accept
+	// =========================================================================
+	if switched, perr := s.checkCapabilities(ctx, req, rolePolicy); perr != nil {
+		if recorder != nil {
+			recorder.RecordError(perr.Code)
+		}
+		return nil, perr
+	} else if switched {
+		if newProviderType, ok := s.config.Load().GetProviderForModel(req.Model); ok {
+			providerType = newProviderType
+		}
+	}
+
+	// =========================================================================
+	// 2d. COST LIMIT - Refuse requests projected to cost more than their cap
+	// =========================================================================
+	if perr := s.checkCostLimit(ctx, req); perr != nil {
+		if recorder != nil {
+			recorder.RecordError(perr.Code)
+		}
+		return nil, perr
+	}
+
+	// =========================================================================
+	// 2e. KILL SWITCH - Refuse models an admin switched off
+	// =========================================================================
+	if perr := s.checkKillSwitch(providerType, req.Model); perr != nil {
+		if recorder != nil {
+			recorder.RecordError(perr.Code)
+		}
+		return nil, perr
+	}
+
 	// =========================================================================
 	// 3. GET CLIENT - Load provider client
 	// =========================================================================
-	client, err := s.getClientForTenant(ctx, "", "default", req.Model)
+	client, providerKeyID, err := s.selectClient(ctx, "", "default", req.Model)
 	if err != nil {
 		if recorder != nil {
 			recorder.RecordError("provider_error")
@@ -767,6 +1342,7 @@ func (s *Service) ChatComplete(ctx context.Context, req *domain.ChatRequest) (*d
 		"request_id", req.RequestID,
 	)
 	var response *domain.ChatResponse
+	servedKeyID := providerKeyID // The fallback's key when a fallback answered
 	if s.isResilienceEnabled(rolePolicy) {
 		// Execute with resilience service
 		response, err = s.resilienceService.ExecuteWithResilience(
@@ -775,23 +1351,39 @@ func (s *Service) ChatComplete(ctx context.Context, req *domain.ChatRequest) (*d
 			rolePolicy.ResiliencePolicy,
 			// Primary execution function
 			func(ctx context.Context) (*domain.ChatResponse, error) {
-				return client.ChatComplete(ctx, req)
+				resp, err := s.chatComplete(s.withQuotaObserver(ctx, client.Provider(), providerKeyID), client, req)
+				s.recordKeyOutcome(ctx, providerKeyID, err)
+				if err != nil {
+					// Even if a fallback answers, the conversation's next turn is routed afresh
+					s.releaseAffinity(req)
+				}
+				return resp, err
 			},
 			// Fallback function (called when primary fails and fallback is configured)
 			func(ctx context.Context, fallbackProvider, fallbackModel string) (*domain.ChatResponse, error) {
-				fallbackClient, err := s.getClientForTenant(ctx, "", "default", fallbackProvider+"/"+fallbackModel)
+				target, err := fallbackTarget(providerType, req.Model, fallbackProvider, fallbackModel)
+				if err != nil {
+					return nil, err
+				}
+				fallbackClient, fallbackKeyID, err := s.selectClient(ctx, "", "default", target)
 				if err != nil {
 					return nil, err
 				}
 				// Create a copy of request with fallback model
 				fallbackReq := *req
-				fallbackReq.Model = fallbackProvider + "/" + fallbackModel
-				return fallbackClient.ChatComplete(ctx, &fallbackReq)
+				fallbackReq.Model = target
+				resp, err := s.chatComplete(s.withQuotaObserver(ctx, fallbackClient.Provider(), fallbackKeyID), fallbackClient, &fallbackReq)
+				s.recordKeyOutcome(ctx, fallbackKeyID, err)
+				if err == nil {
+					servedKeyID = fallbackKeyID
+				}
+				return resp, err
 			},
 		)
 	} else {
 		// Direct execution without resilience
-		response, err = client.ChatComplete(ctx, req)
+		response, err = s.chatComplete(s.withQuotaObserver(ctx, client.Provider(), providerKeyID), client, req)
+		s.recordKeyOutcome(ctx, providerKeyID, err)
 	}
 
 	// Calculate latency
@@ -801,25 +1393,32 @@ func (s *Service) ChatComplete(ctx context.Context, req *domain.ChatRequest) (*d
 	// 5. HANDLE ERRORS - Record health metrics on failure
 	// =========================================================================
 	if err != nil {
+		s.releaseAffinity(req)
+		providerErr := provider.NormalizeError(providerType, err)
 		if recorder != nil {
-			recorder.RecordError("completion_error")
+			recorder.RecordError(providerErr.Code)
 		}
 
 		// Record failure in health tracker
 		if s.healthTracker != nil {
-			s.healthTracker.RecordFailure(ctx, "", string(providerType), req.Model, "request_error")
+			s.healthTracker.RecordFailure(ctx, "", string(providerType), req.Model, providerErr.Code)
 		}
 
-		return nil, err
+		if s.usageRepo != nil {
+			s.recordUsage(ctx, req, "", 0, 0, 0, time.Since(startTime), false, providerErr.Code)
+		}
+		return nil, providerErr
 	}
 
 	// =========================================================================
 	// 6. CALCULATE COST
 	// =========================================================================
 	if response.Usage != nil {
-		if modelCfg, ok := s.config.GetModel(req.Model); ok {
-			response.CostUSD = modelCfg.CalculateCost(
+		req.CachedPromptTokens = response.Usage.CachedPromptTokens
+		if price := s.PriceFor(ctx, req.Model); price != nil {
+			response.CostUSD = price.CostWithCache(
 				int64(response.Usage.PromptTokens),
+				int64(response.Usage.CachedPromptTokens),
 				int64(response.Usage.CompletionTokens),
 			)
 		}
@@ -831,6 +1430,7 @@ func (s *Service) ChatComplete(ctx context.Context, req *domain.ChatRequest) (*d
 				response.CostUSD,
 			)
 		}
+		s.recordKeyUsage(ctx, servedKeyID, int64(response.Usage.PromptTokens), int64(response.Usage.CompletionTokens), response.CostUSD)
 	}
 
 	// Set response metadata
@@ -849,7 +1449,7 @@ func (s *Service) ChatComplete(ctx context.Context, req *domain.ChatRequest) (*d
 			break
 		}
 	}
-	if s.isCacheEnabled(rolePolicy) && response.FinishReason != domain.FinishReasonToolCalls && !hasToolMessages {
+	if s.cacheStoreEnabled(rolePolicy, req) && response.FinishReason != domain.FinishReasonToolCalls && !hasToolMessages {
 		go func() {
 			cacheErr := s.semanticCache.Set(
 				context.Background(),
@@ -870,11 +1470,15 @@ func (s *Service) ChatComplete(ctx context.Context, req *domain.ChatRequest) (*d
 		s.healthTracker.RecordSuccess(ctx, "", string(providerType), req.Model, int(latencyMs))
 	}
 
+	// The client gets the transformed text; the cache and usage record keep it
+	// as generated
+	sent := transformResponse(req, rolePolicy, response)
+
 	// =========================================================================
 	// 9. USAGE TRACKING - Record API usage
 	// =========================================================================
 	if response.Usage != nil && s.usageRepo != nil {
-		s.recordUsage(ctx, req,
+		s.recordUsage(ctx, req, response.Content,
 			int64(response.Usage.PromptTokens),
 			int64(response.Usage.CompletionTokens),
 			response.CostUSD,
@@ -890,12 +1494,12 @@ func (s *Service) ChatComplete(ctx context.Context, req *domain.ChatRequest) (*d
 		s.recordToolCallEvent(ctx, "", req.APIKeyID, toolCall.Function.Name, req.Model, string(providerType), true, "")
 	}
 
-	return response, nil
+	return sent, nil
 }
 
 // CountTokens counts tokens in a request
 func (s *Service) CountTokens(ctx context.Context, req *domain.ChatRequest) (int32, float64, error) {
-	req.Model = s.config.ResolveModel(req.Model)
+	req.Model = s.config.Load().ResolveModel(req.Model)
 
 	client, err := s.providers.GetClientForModel(req.Model)
 	if err != nil {
@@ -909,8 +1513,8 @@ func (s *Service) CountTokens(ctx context.Context, req *domain.ChatRequest) (int
 
 	// Calculate estimated cost
 	var cost float64
-	if modelCfg, ok := s.config.GetModel(req.Model); ok {
-		cost = (float64(tokens) / 1_000_000.0) * modelCfg.InputCostPer1M
+	if price := s.PriceFor(ctx, req.Model); price != nil {
+		cost = price.Cost(int64(tokens), 0)
 	}
 
 	return tokens, cost, nil
@@ -934,7 +1538,7 @@ func (s *Service) ListModels(ctx context.Context, provider string) ([]domain.Mod
 		models = filtered
 	}
 
-	return models, s.config.Aliases, nil
+	return models, s.config.Load().Aliases, nil
 }
 
 // ListProviderModels fetches models from a specific provider using tenant configuration
@@ -948,16 +1552,17 @@ func (s *Service) ListProviderModels(ctx context.Context, tenantSlug string, pro
 
 	// Fetch API key from provider_api_keys table (multi-key support)
 	if s.keySelector != nil && providerCfg.APIKey == "" {
-		apiKey, err := s.keySelector.SelectKey(ctx, tenantSlug, provider)
+		apiKey, err := s.keySelector.SelectKey(ctx, tenantSlug, provider, providerCfg.KeyStrategy)
 		if err != nil {
 			slog.Debug("No API key found for provider", "provider", provider, "tenant_slug", tenantSlug, "tenant_id", tenantID, "error", err)
-			// For Ollama, API key is not required
-			if provider != domain.ProviderOllama {
+			// Self-hosted providers such as Ollama don't need an API key
+			if provider.RequiresAPIKey() {
 				return nil, fmt.Errorf("no API key configured for provider %s", provider)
 			}
 		} else if apiKey != nil {
 			// Populate credentials from the selected key
 			providerCfg.APIKey = apiKey.APIKeyDecrypted
+			providerCfg.APIKeyID = apiKey.ID
 			// For Bedrock, also populate IAM credentials if available
 			if provider == domain.ProviderBedrock {
 				if apiKey.AccessKeyIDDecrypted != "" {
@@ -989,40 +1594,59 @@ func (s *Service) ListProviderModels(ctx context.Context, tenantSlug string, pro
 	return models, nil
 }
 
-// Embed generates embeddings
-func (s *Service) Embed(ctx context.Context, model string, texts []string, dimensions *int32, tenantID string) ([][]float32, int64, error) {
-	model = s.config.ResolveModel(model)
-
-	client, err := s.providers.GetClientForModel(model)
-	if err != nil {
-		return nil, 0, err
+// ValidateProviderKey checks a stored provider key against its provider and
+// records the result on the key. The provider doesn't need to be enabled, so
+// keys can be tested before they are used.
+func (s *Service) ValidateProviderKey(ctx context.Context, tenantSlug, keyID string) (*provider.KeyValidation, error) {
+	if s.keySelector == nil {
+		return nil, fmt.Errorf("provider key management not available")
 	}
-
-	embeddings, tokens, err := client.Embed(ctx, model, texts, dimensions)
+	key, err := s.keySelector.GetKey(ctx, tenantSlug, keyID)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 
-	// Record metrics
-	if s.metrics != nil && tenantID != "" {
-		providerType, _ := s.config.GetProviderForModel(model)
-		s.metrics.TokensInput.WithLabelValues(model, string(providerType), tenantID).Add(float64(tokens))
+	providerCfg := &domain.ProviderConfig{Provider: key.Provider, ConnectionSettings: domain.DefaultConnectionSettings()}
+	if s.pgStore != nil {
+		tenantStore, err := s.pgStore.GetTenantStore(tenantSlug)
+		if err != nil {
+			return nil, fmt.Errorf("failed to access tenant configuration: %w", err)
+		}
+		cfg, err := tenantStore.GetProviderConfig(ctx, key.Provider)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load provider config: %w", err)
+		}
+		if cfg != nil {
+			providerCfg = cfg
+		}
+	}
+	providerCfg.APIKey = key.APIKeyDecrypted
+	providerCfg.APIKeyID = key.ID
+	if key.Provider == domain.ProviderBedrock {
+		providerCfg.AccessKeyID = key.AccessKeyIDDecrypted
+		providerCfg.SecretAccessKey = key.SecretAccessKeyDecrypted
 	}
 
-	return embeddings, tokens, nil
+	result := provider.ValidateCredentials(ctx, key.Provider, providerCfg)
+	if err := s.keySelector.RecordValidation(ctx, tenantSlug, keyID, result); err != nil {
+		slog.Warn("Failed to record provider key validation", "key_id", keyID, "error", err)
+	}
+	return result, nil
 }
 
-// recordUsage records usage to the repository
+// recordUsage records usage to the repository. content is the response text,
+// stored with the request when replay capture is enabled.
 func (s *Service) recordUsage(
 	ctx context.Context,
 	req *domain.ChatRequest,
+	content string,
 	inputTokens, outputTokens int64,
 	costUSD float64,
 	latency time.Duration,
 	success bool,
 	errorCode string,
 ) {
-	providerType, _ := s.config.GetProviderForModel(req.Model)
+	providerType, _ := s.config.Load().GetProviderForModel(req.Model)
 
 	// Extract last user message as prompt
 	var lastUserMessage string
@@ -1046,10 +1670,63 @@ func (s *Service) recordUsage(
 	if lastUserMessage != "" {
 		metadata["prompt"] = lastUserMessage
 	}
+	// Keep the cost basis with the record so later price changes don't obscure it
+	cachedTokens := min(int64(req.CachedPromptTokens), inputTokens)
+	if price := s.PriceFor(ctx, req.Model); price != nil && costUSD > 0 {
+		pricing := map[string]any{
+			"price_id":           price.ID,
+			"source":             string(price.Source),
+			"input_cost_per_1m":  price.InputCostPer1M,
+			"output_cost_per_1m": price.OutputCostPer1M,
+		}
+		if price.CachedInputCostPer1M > 0 {
+			pricing["cached_input_cost_per_1m"] = price.CachedInputCostPer1M
+		}
+		metadata["pricing"] = pricing
+		if cachedTokens > 0 {
+			// What the provider's context cache took off the bill
+			metadata["context_cache"] = map[string]any{
+				"hit_tokens":  cachedTokens,
+				"savings_usd": price.CacheSavings(inputTokens, cachedTokens, outputTokens),
+			}
+		}
+	}
+	if req.OutputViolation != nil {
+		metadata["output_moderation"] = req.OutputViolation
+	}
+	if req.OutputTransform != nil {
+		metadata["output_transform"] = req.OutputTransform
+	}
+	if req.ContextAction != nil {
+		metadata["context_management"] = req.ContextAction
+	}
+	if rl := req.RateLimit; rl != nil && (rl.BurstRequests > 0 || rl.BurstTokens > 0) {
+		metadata["burst_credits"] = map[string]int{
+			"requests": rl.BurstRequests,
+			"tokens":   rl.BurstTokens,
+		}
+	}
+	if req.ReplayOf != "" {
+		metadata["replay_of"] = req.ReplayOf
+	}
+	if req.Embedding != nil {
+		metadata["embedding"] = req.Embedding
+	}
+	if replay := s.config.Load().Replay; replay.CaptureContent && req.Embedding == nil {
+		// Keep the request as sent to the provider so it can be replayed
+		body, err := json.Marshal(req)
+		if err == nil && len(body)+len(content) <= replay.MaxCaptureBytes {
+			metadata["request"] = json.RawMessage(body)
+			metadata["response"] = content
+		} else {
+			metadata["capture_skipped"] = "too_large"
+		}
+	}
 
 	record := &domain.UsageRecord{
 		ID:           uuid.New().String(),
 		APIKeyID:     req.APIKeyID,
+		ProjectID:    req.ProjectID,
 		RequestID:    req.RequestID,
 		Model:        req.Model,
 		Provider:     providerType,
@@ -1063,6 +1740,25 @@ func (s *Service) recordUsage(
 		ToolCalls:    int32(len(req.Tools)),
 		Metadata:     metadata,
 		Timestamp:    time.Now(),
+
+		CachedInputTokens: cachedTokens,
+		ProviderRegion:    req.ProviderRegion,
+		CancelledByClient: errorCode == domain.ErrorCodeCancelled,
+	}
+	if req.StreamTiming != nil {
+		record.TimeToFirstTokenMs = req.StreamTiming.TimeToFirstToken.Milliseconds()
+		record.TokensPerSecond = req.StreamTiming.TokensPerSecond
+	}
+	if req.Experiment != nil {
+		record.ExperimentID = req.Experiment.ExperimentID
+		record.ExperimentVariant = req.Experiment.Variant
+	}
+
+	if s.keyBudgets != nil {
+		s.keyBudgets.Record(req.APIKeyID, costUSD)
+	}
+	if s.convBudgets != nil {
+		s.convBudgets.Record(req.ConversationKey, inputTokens+outputTokens, costUSD)
 	}
 
 	// Record in background
@@ -1092,7 +1788,50 @@ func (s *Service) GetAllowedModelsForRole(ctx context.Context, tenantID, roleID
 func (s *Service) InvalidateTenantProviderClients(tenantID string) {
 	if s.providers != nil {
 		s.providers.InvalidateTenantClients(tenantID)
+		// Rebuild and warm the clients with the new settings
+		go s.WarmProviders(context.Background(), tenantID)
+	}
+}
+
+// handleOutputViolation records content caught by streaming output moderation
+// and flags the request so its usage record carries the violation
+func (s *Service) handleOutputViolation(ctx context.Context, req *domain.ChatRequest, violation *domain.OutputViolation) {
+	req.OutputViolation = violation
+	slog.Warn("Output moderation flagged streamed content",
+		"scanner", violation.Scanner,
+		"detail", violation.Detail,
+		"action", violation.Action,
+		"truncated", violation.Truncated,
+		"model", req.Model,
+		"request_id", req.RequestID)
+
+	severity := 3
+	if violation.Scanner == "secret" || violation.Scanner == "pii" {
+		severity = 5
+	}
+	message := fmt.Sprintf("Streamed output matched the %s scanner", violation.Scanner)
+	if violation.Detail != "" {
+		message += " (" + violation.Detail + ")"
+	}
+	s.recordPolicyViolationEvent(ctx, "", req.APIKeyID, "", "", "output_"+violation.Scanner, severity, message)
+}
+
+// systemPromptText returns the request's system instructions, for leak detection
+func systemPromptText(req *domain.ChatRequest) string {
+	var sb strings.Builder
+	sb.WriteString(req.SystemPrompt)
+	for _, msg := range req.Messages {
+		if msg.Role != "system" {
+			continue
+		}
+		for _, block := range msg.Content {
+			if block.Type == "text" {
+				sb.WriteString("\n")
+				sb.WriteString(block.Text)
+			}
+		}
 	}
+	return sb.String()
 }
 
 // getRolePolicy retrieves the role policy for advanced feature configuration
@@ -1113,9 +1852,41 @@ func (s *Service) getRolePolicy(ctx context.Context, roleID string) *domain.Role
 	return policy
 }
 
-// isCacheEnabled checks if semantic caching is enabled for this request
-func (s *Service) isCacheEnabled(policy *domain.RolePolicy) bool {
-	return s.semanticCache != nil && policy != nil && policy.CachingPolicy.Enabled
+// isCacheEnabled checks if semantic caching is enabled for this request.
+// Cache entries aren't keyed by response_format, so structured and grammar
+// requests skip it.
+func (s *Service) isCacheEnabled(policy *domain.RolePolicy, req *domain.ChatRequest) bool {
+	// The cache key covers the messages, not grounding documents or the system
+	// prompt of an experiment variant, whose responses are measured
+	return s.semanticCache != nil && policy != nil && policy.CachingPolicy.Enabled && !req.ResponseFormat.Structured() &&
+		!wantsGrammar(req) && len(req.Documents) == 0 && req.Experiment == nil
+}
+
+// cacheLookupEnabled checks if a cached response may answer this request.
+// Clients of roles that allow bypass can skip the lookup with a cache directive.
+func (s *Service) cacheLookupEnabled(policy *domain.RolePolicy, req *domain.ChatRequest) bool {
+	if !s.isCacheEnabled(policy, req) {
+		return false
+	}
+	return req.CacheControl == "" || !policy.CachingPolicy.AllowBypass
+}
+
+// cacheHitLookup describes a semantic cache hit for response headers
+func cacheHitLookup(cached *semantic.CacheResult) *domain.CacheLookup {
+	lookup := &domain.CacheLookup{Status: domain.CacheStatusHit, StoredAt: cached.StoredAt}
+	if !cached.Exact {
+		lookup.Status = domain.CacheStatusSemanticHit
+		lookup.Similarity = cached.Similarity
+	}
+	return lookup
+}
+
+// cacheStoreEnabled checks if this request's response may be cached
+func (s *Service) cacheStoreEnabled(policy *domain.RolePolicy, req *domain.ChatRequest) bool {
+	if !s.isCacheEnabled(policy, req) {
+		return false
+	}
+	return req.CacheControl != domain.CacheControlBypass || !policy.CachingPolicy.AllowBypass
 }
 
 // isRoutingEnabled checks if intelligent routing is enabled for this request
@@ -1123,6 +1894,13 @@ func (s *Service) isRoutingEnabled(policy *domain.RolePolicy) bool {
 	return s.router != nil && policy != nil && policy.RoutingPolicy.Enabled
 }
 
+// releaseAffinity unpins the request's conversation from a target that failed it
+func (s *Service) releaseAffinity(req *domain.ChatRequest) {
+	if s.router != nil {
+		s.router.ReleaseAffinity(req)
+	}
+}
+
 // isResilienceEnabled checks if resilience features are enabled for this request
 func (s *Service) isResilienceEnabled(policy *domain.RolePolicy) bool {
 	return s.resilienceService != nil && policy != nil && policy.ResiliencePolicy.Enabled
@@ -0,0 +1,273 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+// defaultHedgeDelay is how long a stream may take to start before it is
+// hedged when the policy sets no delay
+const defaultHedgeDelay = 500 * time.Millisecond
+
+// Legs of a hedged stream, as recorded in telemetry
+const (
+	hedgeLegPrimary = "primary"
+	hedgeLegHedge   = "hedge"
+)
+
+// errStreamClosedEarly is returned for a stream that ended before sending anything
+var errStreamClosedEarly = errors.New("stream closed before sending any events")
+
+// streamLeg is one upstream stream of a (possibly hedged) request
+type streamLeg struct {
+	name     string
+	client   domain.LLMClient
+	provider domain.Provider
+	model    string
+	keyID    string
+	req      *domain.ChatRequest
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	events <-chan domain.StreamEvent
+	first  domain.StreamEvent // Already read from events while racing
+	err    error
+}
+
+// prepare gives the leg its own cancellable context
+func (s *Service) prepare(ctx context.Context, leg *streamLeg) {
+	leg.ctx, leg.cancel = context.WithCancel(s.withQuotaObserver(ctx, leg.client.Provider(), leg.keyID))
+}
+
+// open starts the leg's upstream stream
+func (leg *streamLeg) open() {
+	leg.events, leg.err = leg.client.ChatStream(leg.ctx, leg.req)
+	if leg.err != nil {
+		leg.cancel()
+	}
+}
+
+// race opens the leg, waits for its first event and reports it on done
+func (leg *streamLeg) race(done chan<- *streamLeg) {
+	leg.open()
+	if leg.err == nil {
+		first, ok := <-leg.events
+		if ok {
+			leg.first = first
+		} else {
+			leg.err = errStreamClosedEarly
+			leg.cancel()
+		}
+	}
+	done <- leg
+}
+
+// stream returns the leg's events, starting with any event read while racing
+func (leg *streamLeg) stream() <-chan domain.StreamEvent {
+	if leg.first == nil {
+		return leg.events
+	}
+	out := make(chan domain.StreamEvent, 100)
+	go func() {
+		defer close(out)
+		out <- leg.first
+		for event := range leg.events {
+			out <- event
+		}
+	}()
+	return out
+}
+
+// hedgeTarget returns the provider/model a role's streams are hedged to, if any
+func hedgeTarget(rolePolicy *domain.RolePolicy, primary domain.Provider, primaryModel string) (string, bool) {
+	if rolePolicy == nil || !rolePolicy.ResiliencePolicy.Enabled || !rolePolicy.ResiliencePolicy.Hedging.Enabled {
+		return "", false
+	}
+	h := rolePolicy.ResiliencePolicy.Hedging
+	if h.Model != "" {
+		target := h.Model
+		if h.Provider != "" {
+			target = h.Provider + "/" + h.Model
+		}
+		return target, target != primaryModel
+	}
+
+	chain := append([]domain.FallbackConfig(nil), rolePolicy.ResiliencePolicy.FallbackChain...)
+	sort.SliceStable(chain, func(i, j int) bool { return chain[i].Priority < chain[j].Priority })
+	for _, fc := range chain {
+		if !strings.EqualFold(fc.Provider, string(primary)) && fc.Model != "" {
+			return fc.Provider + "/" + fc.Model, true
+		}
+	}
+	return "", false
+}
+
+// hedgeDelay returns how long to wait for the primary before hedging
+func hedgeDelay(h domain.HedgingConfig) time.Duration {
+	if h.DelayMs > 0 {
+		return time.Duration(h.DelayMs) * time.Millisecond
+	}
+	return defaultHedgeDelay
+}
+
+// openStream starts the primary leg's stream. When the role hedges and the
+// primary hasn't sent its first event within the hedge delay, the same
+// request is also sent to the hedge target; whichever leg answers first is
+// returned and the other is cancelled. The caller must cancel the returned
+// leg when it is done with the stream.
+func (s *Service) openStream(ctx context.Context, req *domain.ChatRequest, rolePolicy *domain.RolePolicy, primary *streamLeg) (*streamLeg, error) {
+	primary.name = hedgeLegPrimary
+	s.prepare(ctx, primary)
+
+	target, ok := hedgeTarget(rolePolicy, primary.provider, primary.model)
+	if !ok {
+		primary.open()
+		return primary, primary.err
+	}
+
+	done := make(chan *streamLeg, 2)
+	go primary.race(done)
+
+	timer := time.NewTimer(hedgeDelay(rolePolicy.ResiliencePolicy.Hedging))
+	defer timer.Stop()
+	select {
+	case leg := <-done:
+		s.recordHedgeRequest(primary, req.RoleID, false)
+		return leg, leg.err
+	case <-timer.C:
+	}
+
+	hedge, err := s.hedgeLeg(ctx, req, target)
+	if err != nil {
+		slog.Warn("Could not start hedge request, waiting for primary",
+			"hedge_model", target, "error", err, "request_id", req.RequestID)
+		s.recordHedgeRequest(primary, req.RoleID, false)
+		leg := <-done
+		return leg, leg.err
+	}
+	s.recordHedgeRequest(primary, req.RoleID, true)
+	slog.Info("Primary stream slow to start, hedging",
+		"model", primary.model, "hedge_model", hedge.model, "request_id", req.RequestID)
+	go hedge.race(done)
+
+	var winner, failed *streamLeg
+	pending := 2
+	for winner == nil && pending > 0 {
+		leg := <-done
+		pending--
+		if leg.err == nil {
+			winner = leg
+		} else if failed == nil {
+			failed = leg
+		}
+	}
+	if winner == nil {
+		return failed, failed.err
+	}
+
+	loser := hedge
+	if winner == hedge {
+		loser = primary
+	}
+	loser.cancel()
+	if s.metrics != nil {
+		s.metrics.RecordHedgeOutcome(string(winner.provider), winner.name, true)
+		s.metrics.RecordHedgeOutcome(string(loser.provider), loser.name, false)
+	}
+	slog.Info("Hedged stream answered",
+		"winner", winner.name, "model", winner.model, "request_id", req.RequestID)
+
+	go s.drainHedgeLoser(context.WithoutCancel(ctx), loser, done, pending > 0)
+	return winner, nil
+}
+
+// hedgeLeg prepares the hedge leg for the given provider/model
+func (s *Service) hedgeLeg(ctx context.Context, req *domain.ChatRequest, target string) (*streamLeg, error) {
+	providerType, ok := s.config.Load().GetProviderForModel(target)
+	if !ok {
+		return nil, fmt.Errorf("unknown provider for model: %s", target)
+	}
+	client, keyID, err := s.selectClient(ctx, "", "default", target)
+	if err != nil {
+		return nil, err
+	}
+
+	hedgeReq := *req
+	hedgeReq.Model = target
+	upstreamReq := &hedgeReq
+	if emulatesDocuments(client, upstreamReq) {
+		upstreamReq = withDocumentsPrompt(upstreamReq)
+	}
+	if emulatesResponseFormat(client, upstreamReq) {
+		upstreamReq = withResponseFormatPrompt(upstreamReq)
+	}
+
+	leg := &streamLeg{
+		name:     hedgeLegHedge,
+		client:   client,
+		provider: providerType,
+		model:    target,
+		keyID:    keyID,
+		req:      upstreamReq,
+	}
+	s.prepare(ctx, leg)
+	return leg, nil
+}
+
+// drainHedgeLoser consumes the cancelled leg's remaining events and records
+// what it probably cost. A loser that was still connecting is waited for first.
+func (s *Service) drainHedgeLoser(ctx context.Context, loser *streamLeg, done <-chan *streamLeg, pending bool) {
+	if pending {
+		<-done
+	}
+
+	var usage *domain.UsageEvent
+	var chars int
+	count := func(event domain.StreamEvent) {
+		switch e := event.(type) {
+		case domain.TextChunk:
+			chars += len(e.Content)
+		case domain.UsageEvent:
+			usage = &e
+		}
+	}
+	switch {
+	case loser.err == nil:
+		count(loser.first)
+		for event := range loser.events {
+			count(event)
+		}
+	case !pending:
+		// It failed on its own before the winner answered; nothing was billed
+		return
+	}
+
+	// A cancelled stream rarely reports usage, so estimate what was sent
+	inputTokens, outputTokens := int64(estimateRequestTokens(loser.req)), int64(chars/4)
+	if usage != nil {
+		inputTokens, outputTokens = int64(usage.PromptTokens), int64(usage.CompletionTokens)
+	}
+	var cost float64
+	if price := s.PriceFor(ctx, loser.model); price != nil {
+		cost = price.Cost(inputTokens, outputTokens)
+	}
+	if s.metrics != nil {
+		s.metrics.RecordHedgeWaste(string(loser.provider), loser.model, cost)
+	}
+	slog.Debug("Cancelled hedge loser",
+		"leg", loser.name, "model", loser.model,
+		"input_tokens", inputTokens, "output_tokens", outputTokens, "wasted_cost_usd", cost)
+}
+
+func (s *Service) recordHedgeRequest(primary *streamLeg, roleID string, hedged bool) {
+	if s.metrics != nil {
+		s.metrics.RecordHedgeRequest(string(primary.provider), roleID, hedged)
+	}
+}
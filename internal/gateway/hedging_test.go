@@ -0,0 +1,102 @@
+package gateway
+
+import (
+	"context"
+	"testing"
+
+	"modelgate/internal/domain"
+)
+
+// streamingClient streams the given events
+type streamingClient struct {
+	domain.LLMClient
+	events []domain.StreamEvent
+}
+
+func (c *streamingClient) ChatStream(ctx context.Context, req *domain.ChatRequest) (<-chan domain.StreamEvent, error) {
+	out := make(chan domain.StreamEvent, len(c.events))
+	for _, e := range c.events {
+		out <- e
+	}
+	close(out)
+	return out, nil
+}
+
+func TestHedgeTarget(t *testing.T) {
+	policy := &domain.RolePolicy{ResiliencePolicy: domain.ResiliencePolicy{
+		Enabled: true,
+		Hedging: domain.HedgingConfig{Enabled: true},
+		FallbackChain: []domain.FallbackConfig{
+			{Provider: "anthropic", Model: "claude-3-haiku", Priority: 2},
+			{Provider: "openai", Model: "gpt-4o", Priority: 0},
+			{Provider: "groq", Model: "llama-3.1-8b", Priority: 1},
+		},
+	}}
+	if target, ok := hedgeTarget(policy, domain.ProviderOpenAI, "openai/gpt-4o-mini"); !ok || target != "groq/llama-3.1-8b" {
+		t.Fatalf("expected the first fallback on another provider, got %q", target)
+	}
+
+	policy.ResiliencePolicy.Hedging.Provider, policy.ResiliencePolicy.Hedging.Model = "mistral", "mistral-small"
+	if target, ok := hedgeTarget(policy, domain.ProviderOpenAI, "openai/gpt-4o-mini"); !ok || target != "mistral/mistral-small" {
+		t.Fatalf("expected the configured hedge target, got %q", target)
+	}
+	if _, ok := hedgeTarget(policy, domain.ProviderMistral, "mistral/mistral-small"); ok {
+		t.Fatal("expected no hedge to the primary model itself")
+	}
+
+	policy.ResiliencePolicy.Hedging.Enabled = false
+	if _, ok := hedgeTarget(policy, domain.ProviderOpenAI, "openai/gpt-4o-mini"); ok {
+		t.Fatal("expected no hedge when hedging is disabled")
+	}
+}
+
+func TestFallbackTarget(t *testing.T) {
+	if target, err := fallbackTarget(domain.ProviderOpenAI, "openai/gpt-4o", "groq", "llama-3.1-8b"); err != nil || target != "groq/llama-3.1-8b" {
+		t.Fatalf("expected the fallback's own model, got %q, %v", target, err)
+	}
+	// OpenRouter without a model serves the requested model
+	if target, err := fallbackTarget(domain.ProviderAnthropic, "anthropic/claude-3.5-sonnet", "openrouter", ""); err != nil || target != "openrouter/anthropic/claude-3.5-sonnet" {
+		t.Fatalf("expected the model through OpenRouter, got %q, %v", target, err)
+	}
+	if _, err := fallbackTarget(domain.ProviderOllama, "ollama/llama3", "openrouter", ""); err == nil {
+		t.Fatal("expected an error for a provider OpenRouter doesn't mirror")
+	}
+	if _, err := fallbackTarget(domain.ProviderOpenAI, "openai/gpt-4o", "groq", ""); err == nil {
+		t.Fatal("expected an error for a fallback without a model")
+	}
+}
+
+func TestStreamLegReplaysFirstEvent(t *testing.T) {
+	leg := &streamLeg{client: &streamingClient{events: []domain.StreamEvent{
+		domain.TextChunk{Content: "Hel"},
+		domain.TextChunk{Content: "lo"},
+		domain.FinishEvent{Reason: domain.FinishReasonStop},
+	}}}
+	leg.ctx, leg.cancel = context.WithCancel(context.Background())
+	defer leg.cancel()
+
+	done := make(chan *streamLeg, 1)
+	leg.race(done)
+	if raced := <-done; raced.err != nil || raced.first == nil {
+		t.Fatalf("expected the first event to be read, got %v", raced.err)
+	}
+
+	var text string
+	var events int
+	for event := range leg.stream() {
+		events++
+		if chunk, ok := event.(domain.TextChunk); ok {
+			text += chunk.Content
+		}
+	}
+	if text != "Hello" || events != 3 {
+		t.Fatalf("expected all 3 events in order, got %d with text %q", events, text)
+	}
+
+	empty := &streamLeg{client: &streamingClient{}}
+	empty.ctx, empty.cancel = context.WithCancel(context.Background())
+	empty.race(done)
+	if raced := <-done; raced.err != errStreamClosedEarly {
+		t.Fatalf("expected errStreamClosedEarly, got %v", raced.err)
+	}
+}
@@ -0,0 +1,38 @@
+package gateway
+
+import (
+	"fmt"
+	"time"
+
+	"modelgate/internal/domain"
+	"modelgate/internal/killswitch"
+)
+
+// SetKillSwitch sets the kill switches checked before calling a provider
+func (s *Service) SetKillSwitch(svc *killswitch.Service) {
+	s.killSwitch = svc
+}
+
+// checkKillSwitch refuses a call to a provider or model an admin switched off,
+// saying why and when traffic resumes
+func (s *Service) checkKillSwitch(providerType domain.Provider, model string) *domain.ProviderError {
+	ks := s.killSwitch.Check(providerType, model)
+	if ks == nil {
+		return nil
+	}
+
+	code, what := domain.ErrorCodeModelDisabled, "model "+ks.Target()
+	if ks.Model == "" {
+		code, what = domain.ErrorCodeProviderDisabled, "provider "+ks.Target()
+	}
+	message := what + " is temporarily disabled by an administrator"
+	if ks.Reason != "" {
+		message += ": " + ks.Reason
+	}
+	perr := domain.NewProviderError(providerType, code, message)
+	if ks.ExpiresAt != nil {
+		perr.Message += fmt.Sprintf("; traffic resumes at %s", ks.ExpiresAt.UTC().Format(time.RFC3339))
+		perr.RetryAfter = time.Until(*ks.ExpiresAt)
+	}
+	return perr
+}
@@ -0,0 +1,96 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"modelgate/internal/domain"
+	"modelgate/internal/routing"
+)
+
+// applyRequestLimits settles a request's deadline and cost cap and records the
+// applied limits on the request. A client's deadline is clamped to the role's
+// request timeout; its cost cap to the role's per-request maximum, which
+// applies on its own as well. The returned context carries the deadline and
+// must be cancelled once the request is done.
+func applyRequestLimits(ctx context.Context, req *domain.ChatRequest, rolePolicy *domain.RolePolicy) (context.Context, context.CancelFunc) {
+	limits := req.Limits
+	if rolePolicy != nil {
+		// Without a client deadline the resilience policy times out each attempt instead
+		if rp := rolePolicy.ResiliencePolicy; rp.Enabled && rp.RequestTimeoutMs > 0 && limits.Timeout > 0 {
+			limits.Timeout = min(limits.Timeout, time.Duration(rp.RequestTimeoutMs)*time.Millisecond)
+		}
+		if bp := rolePolicy.BudgetPolicy; bp.Enabled && bp.MaxCostPerRequest > 0 {
+			if limits.MaxCostUSD == 0 || limits.MaxCostUSD > bp.MaxCostPerRequest {
+				limits.MaxCostUSD = bp.MaxCostPerRequest
+			}
+		}
+	}
+	req.Limits = limits
+	if limits.Timeout == 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, limits.Timeout)
+}
+
+// checkCostLimit refuses a request whose projected cost with its final model
+// is over its cost cap: the estimated prompt plus max_tokens of output. A
+// request without max_tokens gets the most output the cap can pay for.
+// Models without a known price aren't checked.
+func (s *Service) checkCostLimit(ctx context.Context, req *domain.ChatRequest) *domain.ProviderError {
+	limit := req.Limits.MaxCostUSD
+	if limit <= 0 {
+		return nil
+	}
+	price := s.PriceFor(ctx, req.Model)
+	if price == nil {
+		slog.Debug("Model price unknown, cost limit not checked", "model", req.Model, "request_id", req.RequestID)
+		return nil
+	}
+
+	inputTokens := int64(estimateRequestTokens(req))
+	var outputTokens int64
+	if req.MaxTokens != nil {
+		outputTokens = int64(*req.MaxTokens)
+	}
+	projected := price.Cost(inputTokens, outputTokens)
+	if projected <= limit && req.MaxTokens == nil && price.OutputCostPer1M > 0 {
+		affordable := int64((limit - projected) / price.OutputCostPer1M * 1_000_000)
+		if affordable < 1 {
+			projected = price.Cost(inputTokens, 1)
+		} else if affordable < math.MaxInt32 {
+			maxTokens := int32(affordable)
+			req.MaxTokens = &maxTokens
+		}
+	}
+	if projected <= limit {
+		return nil
+	}
+
+	providerType, _ := s.config.Load().GetProviderForModel(req.Model)
+	return domain.NewProviderError(providerType, domain.ErrorCodeCostLimitExceeded,
+		fmt.Sprintf("request is projected to cost $%.6f with model %s, over its $%.6f cost limit; shorten the prompt, lower max_tokens or use a cheaper model",
+			projected, req.Model, limit))
+}
+
+// checkConversationBudget refuses a turn of a conversation that reached one
+// of its role's per-conversation ceilings. Conversations are keyed like
+// sticky routing keys them; the key is kept on the request so its usage is
+// added to the conversation's total.
+func (s *Service) checkConversationBudget(ctx context.Context, req *domain.ChatRequest, rolePolicy *domain.RolePolicy, providerType domain.Provider) *domain.ProviderError {
+	if s.convBudgets == nil || rolePolicy == nil || !rolePolicy.BudgetPolicy.HasConversationCeiling() {
+		return nil
+	}
+	req.ConversationKey = routing.ConversationKey(req)
+	check := s.convBudgets.Check(ctx, req.ConversationKey, req, rolePolicy.BudgetPolicy)
+	if check.Allowed {
+		return nil
+	}
+	if s.metrics != nil {
+		s.metrics.RecordConversationBudgetRejection(req.RoleID, check.Limit)
+	}
+	return domain.NewProviderError(providerType, domain.ErrorCodeConversationBudgetExceeded, check.Reason)
+}
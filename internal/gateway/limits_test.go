@@ -0,0 +1,59 @@
+package gateway
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+)
+
+func TestRequestLimits(t *testing.T) {
+	policy := &domain.RolePolicy{
+		ResiliencePolicy: domain.ResiliencePolicy{Enabled: true, RequestTimeoutMs: 5000},
+		BudgetPolicy:     domain.BudgetPolicy{Enabled: true, MaxCostPerRequest: 0.5},
+	}
+
+	// Client limits are clamped to the role's maxima
+	req := &domain.ChatRequest{Limits: domain.RequestLimits{Timeout: time.Minute, MaxCostUSD: 2}}
+	ctx, cancel := applyRequestLimits(context.Background(), req, policy)
+	defer cancel()
+	if req.Limits.Timeout != 5*time.Second || req.Limits.MaxCostUSD != 0.5 {
+		t.Fatalf("expected limits clamped to 5s and $0.5, got %+v", req.Limits)
+	}
+	if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) > 5*time.Second {
+		t.Fatalf("expected a 5s deadline on the context, got %v", deadline)
+	}
+
+	// Without a client deadline there is none; the role's cost cap still applies
+	req = &domain.ChatRequest{}
+	ctx, cancel = applyRequestLimits(context.Background(), req, policy)
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok || req.Limits.Timeout != 0 || req.Limits.MaxCostUSD != 0.5 {
+		t.Fatalf("expected only the role's cost cap, got %+v", req.Limits)
+	}
+
+	s := &Service{}
+	cfg := config.Default()
+	cfg.Models = map[string]config.ModelConfig{
+		"openai/priced": {Provider: "openai", InputCostPer1M: 1, OutputCostPer1M: 10},
+	}
+	s.config.Store(cfg)
+	prompt := []domain.Message{{Role: "user", Content: []domain.ContentBlock{{Type: "text", Text: strings.Repeat("x", 4000)}}}}
+
+	// Requests projected over the cap are refused
+	maxTokens := int32(100_000)
+	req = &domain.ChatRequest{Model: "openai/priced", Messages: prompt, MaxTokens: &maxTokens, Limits: domain.RequestLimits{MaxCostUSD: 0.01}}
+	if err := s.checkCostLimit(context.Background(), req); err == nil || err.Code != domain.ErrorCodeCostLimitExceeded || err.Status != 402 {
+		t.Fatalf("expected cost_limit_exceeded, got %+v", err)
+	}
+
+	// Without max_tokens the output is capped at what the limit pays for:
+	// 1000 prompt tokens cost $0.001, leaving $0.009 for 900 output tokens
+	req = &domain.ChatRequest{Model: "openai/priced", Messages: prompt, Limits: domain.RequestLimits{MaxCostUSD: 0.01}}
+	if err := s.checkCostLimit(context.Background(), req); err != nil || req.MaxTokens == nil || *req.MaxTokens != 900 {
+		t.Fatalf("expected max_tokens capped at 900, got %v, %v", err, req.MaxTokens)
+	}
+}
@@ -0,0 +1,36 @@
+package gateway
+
+import (
+	"modelgate/internal/domain"
+	"modelgate/internal/policy"
+)
+
+// newOutputTransformer returns the transformer of the role's output transform
+// policy, or nil if it has none
+func newOutputTransformer(rolePolicy *domain.RolePolicy) *policy.OutputTransformer {
+	if rolePolicy == nil {
+		return nil
+	}
+	return policy.NewOutputTransformer(&rolePolicy.OutputTransform)
+}
+
+// transformResponse applies the role's output transform policy to a whole
+// response and notes what changed on the request. It returns a copy, leaving
+// the response as cached untouched, so cached responses are transformed by
+// the policy in force when they are served.
+func transformResponse(req *domain.ChatRequest, rolePolicy *domain.RolePolicy, response *domain.ChatResponse) *domain.ChatResponse {
+	t := newOutputTransformer(rolePolicy)
+	if t == nil {
+		return response
+	}
+	out := *response
+	out.Content = t.Transform(response.Content)
+	if out.Thinking != "" && t.DropsThinking() {
+		out.Thinking = ""
+	}
+	if len(out.Citations) > 0 && t.DropsCitations() {
+		out.Citations = nil
+	}
+	req.OutputTransform = t.Result()
+	return &out
+}
@@ -0,0 +1,44 @@
+package gateway
+
+import "time"
+
+const (
+	// queueStatusDelay is how long a request waits before its first queue status
+	queueStatusDelay = 250 * time.Millisecond
+	// queueStatusInterval is how often the queue status is reported after that
+	queueStatusInterval = time.Second
+)
+
+// QueueStatus is a queued request's place in the dispatcher queue
+type QueueStatus struct {
+	Position int // 1 = next to be picked up
+	Queued   int // Requests waiting in all bands
+	// Wait until a worker picks the request up, from the average processing
+	// time and current worker count; 0 until a request has been processed
+	EstimatedWait time.Duration
+}
+
+// QueueStatus returns the queue status of a submitted request, and false once
+// it is no longer waiting in the queue
+func (d *Dispatcher) QueueStatus(req *DispatchRequest) (QueueStatus, bool) {
+	position, ok := d.queue.position(req)
+	if !ok {
+		return QueueStatus{}, false
+	}
+	return QueueStatus{
+		Position:      position,
+		Queued:        d.queue.len(),
+		EstimatedWait: estimateQueueWait(position, d.AvgProcessingMs(), int(d.activeWorkers.Load())),
+	}, true
+}
+
+// estimateQueueWait estimates the wait of the request at position: the
+// requests ahead of it are processed workers at a time
+func estimateQueueWait(position int, avgProcessingMs float64, workers int) time.Duration {
+	if avgProcessingMs <= 0 || position <= 0 {
+		return 0
+	}
+	workers = max(workers, 1)
+	rounds := (position + workers - 1) / workers
+	return time.Duration(float64(rounds) * avgProcessingMs * float64(time.Millisecond))
+}
@@ -0,0 +1,265 @@
+package gateway
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"modelgate/internal/domain"
+	"modelgate/internal/provider"
+	"modelgate/internal/telemetry"
+)
+
+// regionCooldown is how long a region that failed is skipped when the
+// provider didn't say when to retry
+const regionCooldown = 30 * time.Second
+
+// regionHealth tracks which provider regions are failed over, shared by all
+// clients so a region found down by one request is skipped by the next
+type regionHealth struct {
+	mu   sync.Mutex
+	down map[string]RegionOutage // provider/region -> outage
+}
+
+// RegionOutage is a provider region taken out of rotation after it failed
+type RegionOutage struct {
+	Reason string    // Error code of the failure
+	Until  time.Time // When the region is tried again
+}
+
+func newRegionHealth() *regionHealth {
+	return &regionHealth{down: make(map[string]RegionOutage)}
+}
+
+func (h *regionHealth) outage(provider domain.Provider, region string, now time.Time) (RegionOutage, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	o, ok := h.down[string(provider)+"/"+region]
+	if ok && !now.Before(o.Until) {
+		delete(h.down, string(provider)+"/"+region)
+		return RegionOutage{}, false
+	}
+	return o, ok
+}
+
+func (h *regionHealth) markDown(provider domain.Provider, region, reason string, until time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.down[string(provider)+"/"+region] = RegionOutage{Reason: reason, Until: until}
+}
+
+func (h *regionHealth) markUp(provider domain.Provider, region string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.down, string(provider)+"/"+region)
+}
+
+// RegionOutage reports whether a provider region is currently failed over
+func (s *Service) RegionOutage(provider domain.Provider, region string) (RegionOutage, bool) {
+	return s.regions.outage(provider, region, time.Now())
+}
+
+// enabledRegions returns a provider config's enabled regions by priority
+func enabledRegions(cfg *domain.ProviderConfig) []domain.ProviderRegion {
+	var regions []domain.ProviderRegion
+	for _, r := range cfg.Regions {
+		if r.Enabled {
+			regions = append(regions, r)
+		}
+	}
+	sort.SliceStable(regions, func(i, j int) bool { return regions[i].Priority < regions[j].Priority })
+	return regions
+}
+
+// regionalClients builds a client for each of a provider's enabled regions.
+// Regions whose API key can't be loaded are left out.
+func (s *Service) regionalClients(ctx context.Context, tenantID, tenantSlug string, providerCfg *domain.ProviderConfig) (*regionalClient, error) {
+	rc := &regionalClient{provider: providerCfg.Provider, health: s.regions, metrics: s.metrics}
+	for _, region := range enabledRegions(providerCfg) {
+		cfg := *providerCfg
+		cfg.ActiveRegion = region.Name
+		if region.BaseURL != "" {
+			cfg.BaseURL = region.BaseURL
+		}
+		if region.Region != "" {
+			cfg.Region = region.Region
+		}
+		if region.RegionPrefix != "" {
+			cfg.RegionPrefix = region.RegionPrefix
+		}
+		if region.APIKeyName != "" && s.keySelector != nil {
+			key, err := s.keySelector.SelectNamedKey(ctx, tenantSlug, providerCfg.Provider, region.APIKeyName)
+			if err != nil {
+				slog.Warn("Skipping provider region without its API key",
+					"provider", providerCfg.Provider,
+					"region", region.Name,
+					"error", err)
+				continue
+			}
+			cfg.APIKey, cfg.APIKeyID = key.APIKeyDecrypted, key.ID
+			if key.AccessKeyIDDecrypted != "" {
+				cfg.AccessKeyID, cfg.SecretAccessKey = key.AccessKeyIDDecrypted, key.SecretAccessKeyDecrypted
+			}
+		}
+		client, err := s.providers.GetOrCreateTenantClient(tenantID, providerCfg.Provider, &cfg)
+		if err != nil {
+			return nil, err
+		}
+		rc.regions = append(rc.regions, regionTarget{name: region.Name, client: client})
+	}
+	if len(rc.regions) == 0 {
+		return nil, nil
+	}
+	rc.LLMClient = rc.regions[0].client
+	return rc, nil
+}
+
+// regionalClient sends each chat call to the highest priority region of a
+// provider that isn't failed over. A region that is down or throttled is
+// taken out of rotation and the call moves on to the next region. Other calls
+// go to the first region.
+type regionalClient struct {
+	domain.LLMClient
+	provider domain.Provider
+	regions  []regionTarget
+	health   *regionHealth
+	metrics  *telemetry.Metrics
+}
+
+type regionTarget struct {
+	name   string
+	client domain.LLMClient
+}
+
+// order returns the regions to try: those in rotation by priority, then the
+// failed over ones by when they come back, so a call is still attempted when
+// every region is down
+func (c *regionalClient) order(now time.Time) []regionTarget {
+	var up, down []regionTarget
+	until := make(map[string]time.Time)
+	for _, r := range c.regions {
+		if o, ok := c.health.outage(c.provider, r.name, now); ok {
+			down = append(down, r)
+			until[r.name] = o.Until
+		} else {
+			up = append(up, r)
+		}
+	}
+	sort.SliceStable(down, func(i, j int) bool { return until[down[i].name].Before(until[down[j].name]) })
+	return append(up, down...)
+}
+
+// failover takes a region out of rotation if err means it is down or out of
+// quota, and reports whether the call should move on to the next region
+func (c *regionalClient) failover(region string, err *domain.ProviderError) bool {
+	switch err.Code {
+	case domain.ErrorCodeRateLimit, domain.ErrorCodeInsufficientQuota, domain.ErrorCodeOverloaded,
+		domain.ErrorCodeTimeout, domain.ErrorCodeProviderError, domain.ErrorCodeModelNotFound:
+	default:
+		return false
+	}
+	cooldown := regionCooldown
+	if err.RetryAfter > 0 {
+		cooldown = err.RetryAfter
+	}
+	c.health.markDown(c.provider, region, err.Code, time.Now().Add(cooldown))
+	if c.metrics != nil {
+		c.metrics.RecordRegionFailover(string(c.provider), region, err.Code)
+	}
+	slog.Warn("Provider region failed, trying the next region",
+		"provider", c.provider,
+		"region", region,
+		"code", err.Code,
+		"cooldown", cooldown)
+	return true
+}
+
+func (c *regionalClient) ChatComplete(ctx context.Context, req *domain.ChatRequest) (*domain.ChatResponse, error) {
+	var lastErr error
+	for i, r := range c.order(time.Now()) {
+		resp, err := r.client.ChatComplete(ctx, req)
+		if err == nil {
+			c.health.markUp(c.provider, r.name)
+			req.ProviderRegion = r.name
+			return resp, nil
+		}
+		lastErr = err
+		if i == len(c.regions)-1 || !c.failover(r.name, provider.NormalizeError(c.provider, err)) {
+			break
+		}
+	}
+	return nil, lastErr
+}
+
+// ChatStream fails over when a region refuses the stream or its first event
+// is an error; once a region has streamed anything the stream stays there
+func (c *regionalClient) ChatStream(ctx context.Context, req *domain.ChatRequest) (<-chan domain.StreamEvent, error) {
+	var lastErr error
+	for i, r := range c.order(time.Now()) {
+		last := i == len(c.regions)-1
+		events, err := r.client.ChatStream(ctx, req)
+		if err != nil {
+			lastErr = err
+			if last || !c.failover(r.name, provider.NormalizeError(c.provider, err)) {
+				break
+			}
+			continue
+		}
+
+		first, ok := <-events
+		if errEvent, isErr := first.(domain.ErrorEvent); isErr && !last && errEvent.Err != nil && c.failover(r.name, errEvent.Err) {
+			go drain(events)
+			lastErr = errEvent.Err
+			continue
+		}
+		if !isErrorEvent(first) {
+			c.health.markUp(c.provider, r.name)
+		}
+		req.ProviderRegion = r.name
+
+		out := make(chan domain.StreamEvent, 100)
+		go func() {
+			defer close(out)
+			if ok {
+				select {
+				case out <- first:
+				case <-ctx.Done():
+				}
+			}
+			for event := range events {
+				select {
+				case out <- event:
+				case <-ctx.Done():
+					// Keep draining so the provider's goroutine can finish
+				}
+			}
+		}()
+		return out, nil
+	}
+	return nil, lastErr
+}
+
+// SupportsStructuredOutput reports the provider's structured output support,
+// which emulatesResponseFormat would otherwise not see through the wrapper
+func (c *regionalClient) SupportsStructuredOutput() bool {
+	capable, ok := c.LLMClient.(domain.StructuredOutputCapable)
+	return ok && capable.SupportsStructuredOutput()
+}
+
+// SupportsDocuments reports the provider's document support, for emulatesDocuments
+func (c *regionalClient) SupportsDocuments() bool {
+	capable, ok := c.LLMClient.(domain.DocumentCapable)
+	return ok && capable.SupportsDocuments()
+}
+
+func isErrorEvent(event domain.StreamEvent) bool {
+	_, ok := event.(domain.ErrorEvent)
+	return ok
+}
+
+func drain(events <-chan domain.StreamEvent) {
+	for range events {
+	}
+}
@@ -0,0 +1,79 @@
+package gateway
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+// regionStub answers chat calls with a fixed error or response
+type regionStub struct {
+	streamingClient
+	err   error
+	calls int
+}
+
+func (c *regionStub) ChatComplete(ctx context.Context, req *domain.ChatRequest) (*domain.ChatResponse, error) {
+	c.calls++
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &domain.ChatResponse{Content: "ok"}, nil
+}
+
+func (c *regionStub) ChatStream(ctx context.Context, req *domain.ChatRequest) (<-chan domain.StreamEvent, error) {
+	c.calls++
+	return c.streamingClient.ChatStream(ctx, req)
+}
+
+func TestRegionalFailover(t *testing.T) {
+	throttled := domain.NewProviderError(domain.ProviderAzureOpenAI, domain.ErrorCodeRateLimit, "quota exceeded")
+	throttled.RetryAfter = time.Minute
+	east := &regionStub{err: throttled}
+	west := &regionStub{}
+	health := newRegionHealth()
+	client := &regionalClient{
+		provider: domain.ProviderAzureOpenAI,
+		regions:  []regionTarget{{name: "eastus", client: east}, {name: "westus", client: west}},
+		health:   health,
+	}
+
+	// A throttled region fails over to the next one and is skipped afterwards
+	req := &domain.ChatRequest{}
+	if resp, err := client.ChatComplete(context.Background(), req); err != nil || resp.Content != "ok" || req.ProviderRegion != "westus" {
+		t.Fatalf("expected westus to answer, got %v, region %q", err, req.ProviderRegion)
+	}
+	if outage, down := health.outage(domain.ProviderAzureOpenAI, "eastus", time.Now()); !down || outage.Reason != domain.ErrorCodeRateLimit {
+		t.Fatalf("expected eastus to be failed over, got %+v", outage)
+	}
+	if _, err := client.ChatComplete(context.Background(), &domain.ChatRequest{}); err != nil || east.calls != 1 {
+		t.Fatalf("expected eastus to be skipped while failed over, called %d times", east.calls)
+	}
+
+	// Streams fail over when the first event is a failover error
+	health.markUp(domain.ProviderAzureOpenAI, "eastus")
+	east.events = []domain.StreamEvent{domain.ErrorEvent{Err: domain.NewProviderError(domain.ProviderAzureOpenAI, domain.ErrorCodeOverloaded, "busy")}}
+	west.events = []domain.StreamEvent{domain.TextChunk{Content: "hi"}, domain.FinishEvent{Reason: domain.FinishReasonStop}}
+	req = &domain.ChatRequest{}
+	events, err := client.ChatStream(context.Background(), req)
+	if err != nil {
+		t.Fatalf("ChatStream failed: %v", err)
+	}
+	var got []domain.StreamEvent
+	for event := range events {
+		got = append(got, event)
+	}
+	if len(got) != 2 || got[0] != (domain.TextChunk{Content: "hi"}) || req.ProviderRegion != "westus" {
+		t.Fatalf("expected the westus stream, got %+v from %q", got, req.ProviderRegion)
+	}
+
+	// Errors that aren't about the region are returned as is
+	health.markUp(domain.ProviderAzureOpenAI, "eastus")
+	east.err = domain.NewProviderError(domain.ProviderAzureOpenAI, domain.ErrorCodeContentFilter, "filtered")
+	west.calls = 0
+	if _, err := client.ChatComplete(context.Background(), &domain.ChatRequest{}); err == nil || west.calls != 0 {
+		t.Fatalf("expected a content filter error without failover, got %v", err)
+	}
+}
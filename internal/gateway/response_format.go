@@ -0,0 +1,122 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"modelgate/internal/domain"
+	"modelgate/internal/responses"
+)
+
+// responseFormatAttempts is how many completions are tried before a
+// non-native provider's output is rejected for not matching response_format
+const responseFormatAttempts = 3
+
+// jsonObjectPrompt is added to the system prompt for json_object on providers
+// without native JSON mode
+const jsonObjectPrompt = "You must respond with ONLY a valid JSON object, no additional text."
+
+// emulatesResponseFormat reports whether the gateway has to enforce the
+// request's response_format itself because the client can't
+func emulatesResponseFormat(client domain.LLMClient, req *domain.ChatRequest) bool {
+	if !req.ResponseFormat.Structured() {
+		return false
+	}
+	capable, ok := client.(domain.StructuredOutputCapable)
+	return !ok || !capable.SupportsStructuredOutput()
+}
+
+// withResponseFormatPrompt returns a copy of req whose system prompt tells the
+// model to answer in the requested format
+func withResponseFormatPrompt(req *domain.ChatRequest) *domain.ChatRequest {
+	instructions := jsonObjectPrompt
+	if f := req.ResponseFormat; f.Type == domain.ResponseFormatJSONSchema && f.JSONSchema != nil {
+		instructions = responses.SchemaPrompt(*f.JSONSchema)
+	}
+	out := *req
+	if out.SystemPrompt != "" {
+		out.SystemPrompt += "\n\n" + instructions
+	} else {
+		out.SystemPrompt = instructions
+	}
+	return &out
+}
+
+// validateResponseFormat checks content against the request's format and
+// returns the bare JSON, without code fences or surrounding text
+func validateResponseFormat(content string, f *domain.ResponseFormat) (string, error) {
+	content = strings.TrimSpace(content)
+	if f.Type == domain.ResponseFormatJSONSchema && f.JSONSchema != nil {
+		if err := responses.NewSchemaValidator().Validate(content, f.JSONSchema.Schema); err != nil {
+			return "", err
+		}
+	}
+	if !json.Valid([]byte(content)) {
+		content = responses.ExtractJSON(content)
+	}
+	var obj map[string]any
+	if err := json.Unmarshal([]byte(content), &obj); err != nil {
+		return "", fmt.Errorf("response is not a JSON object: %w", err)
+	}
+	return content, nil
+}
+
+// chatComplete calls the client, enforcing response_format for providers
+// without native structured output: the schema goes into the system prompt and
+// output that doesn't validate is sent back to the model with the error, up to
+// responseFormatAttempts times. Usage covers every attempt. Documents are
+// likewise added to the prompt as numbered sources for providers that can't
+// take them, with citations parsed from the answer.
+func (s *Service) chatComplete(ctx context.Context, client domain.LLMClient, req *domain.ChatRequest) (*domain.ChatResponse, error) {
+	if emulatesDocuments(client, req) {
+		resp, err := s.chatComplete(ctx, client, withDocumentsPrompt(req))
+		if err != nil {
+			return nil, err
+		}
+		citer := &documentCiter{docs: req.Documents}
+		resp.Citations = append(resp.Citations, citer.push(resp.Content)...)
+		return resp, nil
+	}
+	if !emulatesResponseFormat(client, req) {
+		return client.ChatComplete(ctx, req)
+	}
+
+	attempt := withResponseFormatPrompt(req)
+	var usage domain.UsageEvent
+	var lastErr error
+	for i := 0; i < responseFormatAttempts; i++ {
+		resp, err := client.ChatComplete(ctx, attempt)
+		if err != nil {
+			return nil, err
+		}
+		if resp.Usage != nil {
+			usage.PromptTokens += resp.Usage.PromptTokens
+			usage.CompletionTokens += resp.Usage.CompletionTokens
+			usage.TotalTokens += resp.Usage.TotalTokens
+			usage.CachedPromptTokens += resp.Usage.CachedPromptTokens
+		}
+
+		content, err := validateResponseFormat(resp.Content, req.ResponseFormat)
+		if err == nil {
+			resp.Content = content
+			resp.Usage = &usage
+			return resp, nil
+		}
+		lastErr = err
+		slog.Debug("Response did not match response_format, retrying",
+			"model", req.Model, "attempt", i+1, "error", err, "request_id", req.RequestID)
+
+		// Show the model what it got wrong
+		retry := *attempt
+		retry.Messages = append(append([]domain.Message{}, attempt.Messages...),
+			domain.Message{Role: "assistant", Content: []domain.ContentBlock{{Type: "text", Text: resp.Content}}},
+			domain.Message{Role: "user", Content: []domain.ContentBlock{{Type: "text", Text: fmt.Sprintf(
+				"That response was rejected: %v. Respond again with only the corrected JSON.", err)}}},
+		)
+		attempt = &retry
+	}
+	return nil, fmt.Errorf("response did not match response_format after %d attempts: %w", responseFormatAttempts, lastErr)
+}
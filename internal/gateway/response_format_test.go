@@ -0,0 +1,67 @@
+package gateway
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"modelgate/internal/domain"
+)
+
+// scriptedClient answers ChatComplete with canned contents in order
+type scriptedClient struct {
+	domain.LLMClient
+	replies []string
+	seen    []*domain.ChatRequest
+}
+
+func (c *scriptedClient) ChatComplete(ctx context.Context, req *domain.ChatRequest) (*domain.ChatResponse, error) {
+	c.seen = append(c.seen, req)
+	content := c.replies[len(c.seen)-1]
+	return &domain.ChatResponse{Content: content, Usage: &domain.UsageEvent{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}}, nil
+}
+
+func TestChatCompleteEmulatesResponseFormat(t *testing.T) {
+	client := &scriptedClient{replies: []string{
+		`{"city": 42}`,
+		"Here you go:\n```json\n{\"city\": \"Paris\"}\n```",
+	}}
+	req := &domain.ChatRequest{
+		Model:    "anthropic/claude-3-haiku",
+		Messages: []domain.Message{{Role: "user", Content: []domain.ContentBlock{{Type: "text", Text: "Capital of France?"}}}},
+		ResponseFormat: &domain.ResponseFormat{
+			Type: domain.ResponseFormatJSONSchema,
+			JSONSchema: &domain.ResponseSchema{Name: "answer", Schema: map[string]any{
+				"type":       "object",
+				"properties": map[string]any{"city": map[string]any{"type": "string"}},
+				"required":   []any{"city"},
+			}},
+		},
+	}
+
+	resp, err := (&Service{}).chatComplete(context.Background(), client, req)
+	if err != nil {
+		t.Fatalf("chatComplete failed: %v", err)
+	}
+	if resp.Content != `{"city": "Paris"}` {
+		t.Fatalf("expected the bare JSON, got %q", resp.Content)
+	}
+	if resp.Usage.TotalTokens != 30 {
+		t.Fatalf("expected usage for both attempts, got %d", resp.Usage.TotalTokens)
+	}
+	if len(client.seen) != 2 || !strings.Contains(client.seen[0].SystemPrompt, "JSON Schema") {
+		t.Fatalf("expected two attempts with schema instructions, got %d", len(client.seen))
+	}
+	if retry := client.seen[1].Messages; len(retry) != 3 || retry[1].Role != "assistant" {
+		t.Fatalf("expected the rejected answer to be sent back, got %+v", retry)
+	}
+	if req.SystemPrompt != "" || len(req.Messages) != 1 {
+		t.Fatal("expected the caller's request to be left unchanged")
+	}
+
+	client = &scriptedClient{replies: []string{"no", "still no", "nope"}}
+	req.ResponseFormat = &domain.ResponseFormat{Type: domain.ResponseFormatJSONObject}
+	if _, err := (&Service{}).chatComplete(context.Background(), client, req); err == nil || len(client.seen) != responseFormatAttempts {
+		t.Fatalf("expected failure after %d attempts, got %v after %d", responseFormatAttempts, err, len(client.seen))
+	}
+}
@@ -0,0 +1,35 @@
+package gateway
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+
+	"modelgate/internal/cache/embedding"
+	"modelgate/internal/domain"
+)
+
+// cacheStreamedResponse stores a streamed response in the semantic cache,
+// which also serves exact matches by prompt hash. It is called once the
+// provider closed a stream that finished cleanly, with a response assembled
+// from the buffered chunks, and stores it in the background on its own copy
+// of the messages. While an identical prompt's stream is being stored,
+// further ones are skipped rather than writing the same entry again.
+func (s *Service) cacheStreamedResponse(req *domain.ChatRequest, model string, providerType domain.Provider, resp *domain.ChatResponse, policy domain.CachingPolicy) {
+	messages := slices.Clone(req.Messages)
+	key := req.RoleID + "\x00" + model + "\x00" + embedding.HashPrompt(embedding.NormalizePrompt(messages))
+	if _, busy := s.streamFills.LoadOrStore(key, struct{}{}); busy {
+		slog.Debug("Identical streaming response already being cached", "request_id", req.RequestID)
+		return
+	}
+
+	roleID, requestID := req.RoleID, req.RequestID
+	go func() {
+		defer s.streamFills.Delete(key)
+		if err := s.semanticCache.Set(context.Background(), roleID, model, string(providerType), messages, resp, policy); err != nil {
+			slog.Warn("Failed to cache streaming response", "error", err, "request_id", requestID)
+			return
+		}
+		slog.Debug("Cached streaming response", "request_id", requestID, "content_length", len(resp.Content))
+	}()
+}
@@ -0,0 +1,76 @@
+package gateway
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"modelgate/internal/cache/semantic"
+	"modelgate/internal/domain"
+)
+
+// blockingCache holds each Set until released
+type blockingCache struct {
+	semantic.CacheService
+	release chan struct{}
+	mu      sync.Mutex
+	stored  []string
+}
+
+func (c *blockingCache) Set(ctx context.Context, roleID, model, provider string, messages []domain.Message, response *domain.ChatResponse, config domain.CachingPolicy) error {
+	<-c.release
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stored = append(c.stored, response.Content)
+	return nil
+}
+
+func (c *blockingCache) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.stored)
+}
+
+func TestCacheStreamedResponseOncePerPrompt(t *testing.T) {
+	cache := &blockingCache{release: make(chan struct{})}
+	s := &Service{semanticCache: cache}
+	req := &domain.ChatRequest{RoleID: "r1", Messages: []domain.Message{
+		{Role: "user", Content: []domain.ContentBlock{{Type: "text", Text: "What is the capital of France?"}}},
+	}}
+	resp := &domain.ChatResponse{Content: "Paris"}
+
+	// Concurrent identical streams finishing together store one entry
+	s.cacheStreamedResponse(req, "gpt-4o", "openai", resp, domain.CachingPolicy{Enabled: true})
+	s.cacheStreamedResponse(req, "gpt-4o", "openai", resp, domain.CachingPolicy{Enabled: true})
+	// The messages were copied, so the request can change meanwhile
+	req.Messages[0] = domain.Message{Role: "user"}
+	close(cache.release)
+
+	deadline := time.Now().Add(time.Second)
+	for !syncMapEmpty(&s.streamFills) && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if cache.count() != 1 {
+		t.Fatalf("expected one store, got %d", cache.count())
+	}
+
+	// Once stored, a later stream of the prompt stores again
+	req.Messages[0] = domain.Message{Role: "user", Content: []domain.ContentBlock{{Type: "text", Text: "What is the capital of France?"}}}
+	s.cacheStreamedResponse(req, "gpt-4o", "openai", resp, domain.CachingPolicy{Enabled: true})
+	for cache.count() < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if cache.count() != 2 {
+		t.Fatalf("expected a second store, got %d", cache.count())
+	}
+}
+
+func syncMapEmpty(m *sync.Map) bool {
+	empty := true
+	m.Range(func(any, any) bool {
+		empty = false
+		return false
+	})
+	return empty
+}
@@ -0,0 +1,315 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+const (
+	// defaultThrottleWait is how long a request waits for a saturated throttle
+	// when the throttle sets no wait
+	defaultThrottleWait = 10 * time.Second
+	// throttleWindow is the window tokens-per-minute limits are counted over
+	throttleWindow = time.Minute
+	// concurrencyRetryAfter is the Retry-After sent when concurrency is the
+	// limit, since how long running requests take isn't known
+	concurrencyRetryAfter = time.Second
+)
+
+// errThrottled is the underlying error of requests rejected by a throttle.
+// They never reached the provider, so they don't count against the key.
+var errThrottled = errors.New("provider throttle saturated")
+
+// throttleSpend is tokens charged to a throttle's minute window
+type throttleSpend struct {
+	at     time.Time
+	tokens int
+}
+
+// throttleState is what is in flight against one throttle
+type throttleState struct {
+	inFlight int
+	spends   []*throttleSpend
+}
+
+// throttler enforces the concurrency and tokens-per-minute limits of provider
+// configs. State is kept per provider and model, while the limits are read
+// from the provider config of each request, so changes apply immediately.
+type throttler struct {
+	mu       sync.Mutex
+	states   map[string]*throttleState
+	released chan struct{} // Closed and replaced whenever capacity frees up
+	now      func() time.Time
+}
+
+func newThrottler() *throttler {
+	return &throttler{
+		states:   make(map[string]*throttleState),
+		released: make(chan struct{}),
+		now:      time.Now,
+	}
+}
+
+// throttleTicket is a request admitted by the throttler
+type throttleTicket struct {
+	t      *throttler
+	states []*throttleState
+	spends []*throttleSpend
+}
+
+// matchingThrottles returns the throttles that apply to model: those for the
+// whole provider and those for the model, with or without its provider prefix
+func matchingThrottles(throttles []domain.ProviderThrottle, model string) []domain.ProviderThrottle {
+	bare := model
+	if i := strings.Index(model, "/"); i >= 0 {
+		bare = model[i+1:]
+	}
+	var out []domain.ProviderThrottle
+	for _, th := range throttles {
+		if th.Model == "" || th.Model == model || th.Model == bare {
+			out = append(out, th)
+		}
+	}
+	return out
+}
+
+// acquire waits until every throttle has room for a request of about tokens
+// tokens and admits it. When the wait runs out it returns a rate limit
+// ProviderError whose RetryAfter is when capacity is expected to free up.
+func (t *throttler) acquire(ctx context.Context, provider domain.Provider, model string, throttles []domain.ProviderThrottle, tokens int) (*throttleTicket, error) {
+	// The longest wait any matching throttle allows
+	wait := time.Duration(0)
+	for _, th := range throttles {
+		wait = max(wait, time.Duration(th.MaxQueueWaitSec)*time.Second)
+	}
+	if wait == 0 {
+		wait = defaultThrottleWait
+	}
+	deadline := t.now().Add(wait)
+
+	for {
+		t.mu.Lock()
+		ticket, retryAfter := t.tryAcquire(provider, throttles, tokens)
+		released := t.released
+		t.mu.Unlock()
+		if ticket != nil {
+			return ticket, nil
+		}
+
+		remaining := deadline.Sub(t.now())
+		if remaining <= 0 {
+			perr := domain.NewProviderError(provider, domain.ErrorCodeRateLimit,
+				fmt.Sprintf("gateway throttle for %s is saturated", model))
+			perr.RetryAfter = retryAfter
+			perr.Err = errThrottled
+			return nil, perr
+		}
+
+		timer := time.NewTimer(min(retryAfter, remaining))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-released:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// tryAcquire admits the request if every throttle has room, and otherwise
+// returns how long until the fullest one is expected to. Callers hold t.mu.
+func (t *throttler) tryAcquire(provider domain.Provider, throttles []domain.ProviderThrottle, tokens int) (*throttleTicket, time.Duration) {
+	now := t.now()
+	var retryAfter time.Duration
+	states := make([]*throttleState, len(throttles))
+	for i, th := range throttles {
+		st := t.state(provider, th.Model)
+		st.prune(now)
+		states[i] = st
+
+		if th.MaxConcurrent > 0 && st.inFlight >= th.MaxConcurrent {
+			retryAfter = max(retryAfter, concurrencyRetryAfter)
+		}
+		if th.TokensPerMinute > 0 {
+			if wait := st.tokenWait(now, tokens, th.TokensPerMinute); wait > 0 {
+				retryAfter = max(retryAfter, wait)
+			}
+		}
+	}
+	if retryAfter > 0 {
+		return nil, retryAfter
+	}
+
+	ticket := &throttleTicket{t: t, states: states}
+	for _, st := range states {
+		spend := &throttleSpend{at: now, tokens: tokens}
+		st.inFlight++
+		st.spends = append(st.spends, spend)
+		ticket.spends = append(ticket.spends, spend)
+	}
+	return ticket, 0
+}
+
+func (t *throttler) state(provider domain.Provider, model string) *throttleState {
+	key := string(provider) + "|" + model
+	st, ok := t.states[key]
+	if !ok {
+		st = &throttleState{}
+		t.states[key] = st
+	}
+	return st
+}
+
+// prune drops spends that have left the minute window
+func (st *throttleState) prune(now time.Time) {
+	i := 0
+	for i < len(st.spends) && now.Sub(st.spends[i].at) >= throttleWindow {
+		i++
+	}
+	st.spends = st.spends[i:]
+}
+
+// tokenWait returns how long until tokens more fit under limit. A request
+// larger than the whole limit is admitted once the window is empty, so it
+// can't wait forever.
+func (st *throttleState) tokenWait(now time.Time, tokens, limit int) time.Duration {
+	used := 0
+	for _, sp := range st.spends {
+		used += sp.tokens
+	}
+	if used+tokens <= limit || used == 0 {
+		return 0
+	}
+	for _, sp := range st.spends {
+		used -= sp.tokens
+		if used+tokens <= limit || used == 0 {
+			return sp.at.Add(throttleWindow).Sub(now)
+		}
+	}
+	return throttleWindow
+}
+
+// release frees the request's concurrency slot and corrects its token
+// estimate to what it used. tokens <= 0 keeps the estimate.
+func (tk *throttleTicket) release(tokens int) {
+	t := tk.t
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i, st := range tk.states {
+		st.inFlight--
+		if tokens > 0 {
+			tk.spends[i].tokens = tokens
+		}
+	}
+	close(t.released)
+	t.released = make(chan struct{})
+}
+
+// throttledClient applies a provider config's throttles to chat calls. Other
+// calls go straight to the provider.
+type throttledClient struct {
+	domain.LLMClient
+	throttler *throttler
+	throttles []domain.ProviderThrottle
+}
+
+// throttle wraps client in the provider config's throttles, if it has any
+func (t *throttler) throttle(client domain.LLMClient, throttles []domain.ProviderThrottle) domain.LLMClient {
+	if len(throttles) == 0 {
+		return client
+	}
+	return &throttledClient{LLMClient: client, throttler: t, throttles: throttles}
+}
+
+// unthrottled returns the provider client behind a throttled client, for
+// callers that need its optional interfaces. For a multi-region provider
+// that is the client of its first region.
+func unthrottled(client domain.LLMClient) domain.LLMClient {
+	if tc, ok := client.(*throttledClient); ok {
+		client = tc.LLMClient
+	}
+	if rc, ok := client.(*regionalClient); ok {
+		return rc.LLMClient
+	}
+	return client
+}
+
+// requestTokens estimates what a request charges against a TPM limit: its
+// prompt plus the completion it allows
+func requestTokens(req *domain.ChatRequest) int {
+	tokens := estimateRequestTokens(req)
+	if req.MaxTokens != nil {
+		tokens += int(*req.MaxTokens)
+	}
+	return tokens
+}
+
+func (c *throttledClient) acquire(ctx context.Context, req *domain.ChatRequest) (*throttleTicket, error) {
+	return c.throttler.acquire(ctx, c.Provider(), req.Model, matchingThrottles(c.throttles, req.Model), requestTokens(req))
+}
+
+func (c *throttledClient) ChatComplete(ctx context.Context, req *domain.ChatRequest) (*domain.ChatResponse, error) {
+	ticket, err := c.acquire(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.LLMClient.ChatComplete(ctx, req)
+	used := 0
+	if resp != nil && resp.Usage != nil {
+		used = int(resp.Usage.TotalTokens)
+	}
+	ticket.release(used)
+	return resp, err
+}
+
+// ChatStream holds the throttle until the stream ends, when the usage event
+// tells what the request really cost
+func (c *throttledClient) ChatStream(ctx context.Context, req *domain.ChatRequest) (<-chan domain.StreamEvent, error) {
+	ticket, err := c.acquire(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	events, err := c.LLMClient.ChatStream(ctx, req)
+	if err != nil {
+		ticket.release(0)
+		return nil, err
+	}
+
+	out := make(chan domain.StreamEvent, 100)
+	go func() {
+		defer close(out)
+		used := 0
+		defer func() { ticket.release(used) }()
+		for event := range events {
+			if usage, ok := event.(domain.UsageEvent); ok {
+				used = int(usage.TotalTokens)
+			}
+			select {
+			case out <- event:
+			case <-ctx.Done():
+				// Keep draining so the provider's goroutine can finish
+			}
+		}
+	}()
+	return out, nil
+}
+
+// SupportsStructuredOutput reports the provider's structured output support,
+// which emulatesResponseFormat would otherwise not see through the wrapper
+func (c *throttledClient) SupportsStructuredOutput() bool {
+	capable, ok := c.LLMClient.(domain.StructuredOutputCapable)
+	return ok && capable.SupportsStructuredOutput()
+}
+
+// SupportsDocuments reports the provider's document support, for emulatesDocuments
+func (c *throttledClient) SupportsDocuments() bool {
+	capable, ok := c.LLMClient.(domain.DocumentCapable)
+	return ok && capable.SupportsDocuments()
+}
@@ -0,0 +1,106 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+func TestThrottleConcurrencyQueues(t *testing.T) {
+	th := newThrottler()
+	throttles := []domain.ProviderThrottle{{MaxConcurrent: 1}}
+
+	first, err := th.acquire(context.Background(), domain.ProviderOpenAI, "openai/gpt-4o", throttles, 10)
+	if err != nil {
+		t.Fatalf("first acquire: %v", err)
+	}
+
+	admitted := make(chan error, 1)
+	go func() {
+		ticket, err := th.acquire(context.Background(), domain.ProviderOpenAI, "openai/gpt-4o", throttles, 10)
+		if err == nil {
+			ticket.release(0)
+		}
+		admitted <- err
+	}()
+
+	select {
+	case err := <-admitted:
+		t.Fatalf("expected the second request to wait, got %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	first.release(0)
+	select {
+	case err := <-admitted:
+		if err != nil {
+			t.Fatalf("expected the queued request to be admitted, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("queued request was not admitted after release")
+	}
+}
+
+func TestThrottleSaturatedReturnsRetryAfter(t *testing.T) {
+	th := newThrottler()
+	now := time.Unix(0, 0)
+	th.now = func() time.Time {
+		now = now.Add(20 * time.Second) // Each look at the clock outlasts the default wait
+		return now
+	}
+	throttles := []domain.ProviderThrottle{{Model: "gpt-4o", MaxConcurrent: 1}}
+
+	if _, err := th.acquire(context.Background(), domain.ProviderOpenAI, "openai/gpt-4o", throttles, 10); err != nil {
+		t.Fatalf("first acquire: %v", err)
+	}
+	_, err := th.acquire(context.Background(), domain.ProviderOpenAI, "openai/gpt-4o", throttles, 10)
+	var perr *domain.ProviderError
+	if !errors.As(err, &perr) || !errors.Is(err, errThrottled) {
+		t.Fatalf("expected a throttled provider error, got %v", err)
+	}
+	if perr.Status != http.StatusTooManyRequests || perr.RetryAfter != concurrencyRetryAfter {
+		t.Fatalf("expected 429 with Retry-After %v, got %d with %v", concurrencyRetryAfter, perr.Status, perr.RetryAfter)
+	}
+
+	// Other models of the provider aren't affected
+	if _, err := th.acquire(context.Background(), domain.ProviderOpenAI, "openai/gpt-4o-mini",
+		matchingThrottles(throttles, "openai/gpt-4o-mini"), 10); err != nil {
+		t.Fatalf("expected another model to be admitted, got %v", err)
+	}
+}
+
+func TestThrottleTokensPerMinute(t *testing.T) {
+	th := newThrottler()
+	now := time.Unix(0, 0)
+	th.now = func() time.Time { return now }
+	throttles := []domain.ProviderThrottle{{TokensPerMinute: 100}}
+
+	th.mu.Lock()
+	defer th.mu.Unlock()
+	ticket, _ := th.tryAcquire(domain.ProviderAnthropic, throttles, 80)
+	if ticket == nil {
+		t.Fatal("expected the first request to fit")
+	}
+
+	now = now.Add(10 * time.Second)
+	if ticket, retryAfter := th.tryAcquire(domain.ProviderAnthropic, throttles, 30); ticket != nil || retryAfter != 50*time.Second {
+		t.Fatalf("expected to wait until the first spend leaves the window, got retry after %v", retryAfter)
+	}
+
+	// The request used less than estimated
+	th.mu.Unlock()
+	ticket.release(20)
+	th.mu.Lock()
+	if ticket, _ := th.tryAcquire(domain.ProviderAnthropic, throttles, 30); ticket == nil {
+		t.Fatal("expected the corrected spend to leave room")
+	}
+
+	// A request larger than the limit gets in once the window is empty
+	now = now.Add(time.Minute)
+	if ticket, _ := th.tryAcquire(domain.ProviderAnthropic, throttles, 500); ticket == nil {
+		t.Fatal("expected an oversized request to be admitted into an empty window")
+	}
+}
@@ -0,0 +1,91 @@
+package gateway
+
+import (
+	"context"
+	"log/slog"
+
+	"modelgate/internal/domain"
+)
+
+// WarmProviders builds the clients of every enabled provider of a tenant, one
+// per enabled API key and regional deployment, and opens their connections so
+// the first requests don't pay for DNS, TCP and TLS. It does nothing unless
+// [connection_warmup] is enabled.
+func (s *Service) WarmProviders(ctx context.Context, tenantSlug string) {
+	if s.pgStore == nil || !s.config.Load().Warmup.Enabled {
+		return
+	}
+	// Single-tenant mode caches clients under the slug, see selectClient
+	tenantID := tenantSlug
+
+	tenantStore, err := s.pgStore.GetTenantStore(tenantSlug)
+	if err != nil {
+		slog.Warn("Skipping provider warm-up", "tenant_slug", tenantSlug, "error", err)
+		return
+	}
+	listed, err := tenantStore.ListProviderConfigs(ctx)
+	if err != nil {
+		slog.Warn("Skipping provider warm-up", "tenant_slug", tenantSlug, "error", err)
+		return
+	}
+
+	for _, p := range listed {
+		if !p.Enabled {
+			continue
+		}
+		providerCfg, err := tenantStore.GetProviderConfig(ctx, p.Provider)
+		if err != nil || providerCfg == nil {
+			slog.Warn("Skipping provider warm-up", "provider", p.Provider, "error", err)
+			continue
+		}
+		if len(enabledRegions(providerCfg)) > 0 {
+			if _, err := s.regionalClients(ctx, tenantID, tenantSlug, providerCfg); err != nil {
+				slog.Warn("Skipping provider warm-up", "provider", p.Provider, "error", err)
+			}
+			continue
+		}
+		for _, cfg := range s.keyedProviderConfigs(ctx, tenantSlug, providerCfg) {
+			if _, err := s.providers.GetOrCreateTenantClient(tenantID, cfg.Provider, cfg); err != nil {
+				slog.Warn("Skipping provider warm-up", "provider", p.Provider, "api_key_id", cfg.APIKeyID, "error", err)
+			}
+		}
+	}
+
+	s.providers.WarmTenantClients(ctx, tenantID)
+}
+
+// keyedProviderConfigs returns a copy of providerCfg for each of the
+// provider's enabled API keys, as the key selector would fill it in. Providers
+// without stored keys get providerCfg itself when they don't need one.
+func (s *Service) keyedProviderConfigs(ctx context.Context, tenantSlug string, providerCfg *domain.ProviderConfig) []*domain.ProviderConfig {
+	if s.keySelector == nil {
+		return []*domain.ProviderConfig{providerCfg}
+	}
+	keys, err := s.keySelector.ListKeys(ctx, tenantSlug, providerCfg.Provider)
+	if err != nil {
+		slog.Debug("No API keys to warm for provider", "provider", providerCfg.Provider, "error", err)
+	}
+
+	var configs []*domain.ProviderConfig
+	for _, key := range keys {
+		if !key.Enabled {
+			continue
+		}
+		cfg := *providerCfg
+		cfg.APIKey = key.APIKeyDecrypted
+		cfg.APIKeyID = key.ID
+		if providerCfg.Provider == domain.ProviderBedrock {
+			if key.AccessKeyIDDecrypted != "" {
+				cfg.AccessKeyID = key.AccessKeyIDDecrypted
+			}
+			if key.SecretAccessKeyDecrypted != "" {
+				cfg.SecretAccessKey = key.SecretAccessKeyDecrypted
+			}
+		}
+		configs = append(configs, &cfg)
+	}
+	if len(configs) == 0 && !providerCfg.Provider.RequiresAPIKey() {
+		configs = append(configs, providerCfg)
+	}
+	return configs
+}
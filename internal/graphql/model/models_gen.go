@@ -11,18 +11,48 @@ import (
 )
 
 type APIKey struct {
-	ID             string     `json:"id"`
-	Name           string     `json:"name"`
-	KeyPrefix      string     `json:"keyPrefix"`
-	Role           *Role      `json:"role,omitempty"`
-	Group          *Group     `json:"group,omitempty"`
-	LastUsedAt     *time.Time `json:"lastUsedAt,omitempty"`
-	CreatedAt      time.Time  `json:"createdAt"`
-	CreatedBy      *string    `json:"createdBy,omitempty"`
-	CreatedByEmail *string    `json:"createdByEmail,omitempty"`
-	ExpiresAt      *time.Time `json:"expiresAt,omitempty"`
-	IsExpired      bool       `json:"isExpired"`
-	Revoked        bool       `json:"revoked"`
+	ID             string              `json:"id"`
+	Name           string              `json:"name"`
+	KeyPrefix      string              `json:"keyPrefix"`
+	Role           *Role               `json:"role,omitempty"`
+	Group          *Group              `json:"group,omitempty"`
+	LastUsedAt     *time.Time          `json:"lastUsedAt,omitempty"`
+	CreatedAt      time.Time           `json:"createdAt"`
+	CreatedBy      *string             `json:"createdBy,omitempty"`
+	CreatedByEmail *string             `json:"createdByEmail,omitempty"`
+	ExpiresAt      *time.Time          `json:"expiresAt,omitempty"`
+	IsExpired      bool                `json:"isExpired"`
+	Revoked        bool                `json:"revoked"`
+	AllowedCidrs   []string            `json:"allowedCidrs"`
+	ProjectID      *string             `json:"projectId,omitempty"`
+	ProjectName    *string             `json:"projectName,omitempty"`
+	Budget         *APIKeyBudget       `json:"budget,omitempty"`
+	BudgetStatus   *APIKeyBudgetStatus `json:"budgetStatus,omitempty"`
+	OwnerID        *string             `json:"ownerId,omitempty"`
+	OwnerEmail     *string             `json:"ownerEmail,omitempty"`
+}
+
+type APIKeyBudget struct {
+	DailySoftLimitUsd   float64 `json:"dailySoftLimitUSD"`
+	DailyHardLimitUsd   float64 `json:"dailyHardLimitUSD"`
+	MonthlySoftLimitUsd float64 `json:"monthlySoftLimitUSD"`
+	MonthlyHardLimitUsd float64 `json:"monthlyHardLimitUSD"`
+	AlertWebhook        *string `json:"alertWebhook,omitempty"`
+}
+
+type APIKeyBudgetInput struct {
+	DailySoftLimitUsd   *float64 `json:"dailySoftLimitUSD,omitempty"`
+	DailyHardLimitUsd   *float64 `json:"dailyHardLimitUSD,omitempty"`
+	MonthlySoftLimitUsd *float64 `json:"monthlySoftLimitUSD,omitempty"`
+	MonthlyHardLimitUsd *float64 `json:"monthlyHardLimitUSD,omitempty"`
+	AlertWebhook        *string  `json:"alertWebhook,omitempty"`
+}
+
+type APIKeyBudgetStatus struct {
+	DailySpentUsd       float64  `json:"dailySpentUSD"`
+	MonthlySpentUsd     float64  `json:"monthlySpentUSD"`
+	DailyRemainingUsd   *float64 `json:"dailyRemainingUSD,omitempty"`
+	MonthlyRemainingUsd *float64 `json:"monthlyRemainingUSD,omitempty"`
 }
 
 type APIKeyUsage struct {
@@ -39,13 +69,22 @@ type APIKeyWithSecret struct {
 	Secret string  `json:"secret"`
 }
 
+type AddCustomDomainInput struct {
+	Hostname       string  `json:"hostname"`
+	TenantSlug     *string `json:"tenantSlug,omitempty"`
+	CertificatePem *string `json:"certificatePem,omitempty"`
+	PrivateKeyPem  *string `json:"privateKeyPem,omitempty"`
+}
+
 type AddProviderAPIKeyInput struct {
-	Provider        Provider `json:"provider"`
-	APIKey          *string  `json:"apiKey,omitempty"`
-	AccessKeyID     *string  `json:"accessKeyId,omitempty"`
-	SecretAccessKey *string  `json:"secretAccessKey,omitempty"`
-	Name            *string  `json:"name,omitempty"`
-	Priority        int      `json:"priority"`
+	Provider          Provider `json:"provider"`
+	APIKey            *string  `json:"apiKey,omitempty"`
+	AccessKeyID       *string  `json:"accessKeyId,omitempty"`
+	SecretAccessKey   *string  `json:"secretAccessKey,omitempty"`
+	Name              *string  `json:"name,omitempty"`
+	Priority          int      `json:"priority"`
+	TrafficPercent    *int     `json:"trafficPercent,omitempty"`
+	RollbackErrorRate *float64 `json:"rollbackErrorRate,omitempty"`
 }
 
 type AdvancedMetrics struct {
@@ -71,9 +110,40 @@ type AgentDashboardStats struct {
 	RiskAssessment     *RiskAssessment      `json:"riskAssessment"`
 }
 
+type ApproveMCPToolInput struct {
+	ApprovalID string             `json:"approvalId"`
+	Visibility *MCPToolVisibility `json:"visibility,omitempty"`
+	Reason     *string            `json:"reason,omitempty"`
+}
+
 type ApproveRegistrationInput struct {
 	RequestID string      `json:"requestId"`
 	Tier      *TenantTier `json:"tier,omitempty"`
+	Role      *string     `json:"role,omitempty"`
+}
+
+type AttestationKey struct {
+	ID        string     `json:"id"`
+	PublicKey string     `json:"publicKey"`
+	Active    bool       `json:"active"`
+	CreatedBy *string    `json:"createdBy,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	RetiredAt *time.Time `json:"retiredAt,omitempty"`
+}
+
+type AuditExportJob struct {
+	ID          string            `json:"id"`
+	Status      AuditExportStatus `json:"status"`
+	Format      AuditExportFormat `json:"format"`
+	RowCount    int               `json:"rowCount"`
+	SizeBytes   int               `json:"sizeBytes"`
+	RequestedBy *string           `json:"requestedBy,omitempty"`
+	ObjectKey   *string           `json:"objectKey,omitempty"`
+	DownloadURL *string           `json:"downloadUrl,omitempty"`
+	Error       *string           `json:"error,omitempty"`
+	CreatedAt   time.Time         `json:"createdAt"`
+	CompletedAt *time.Time        `json:"completedAt,omitempty"`
+	ExpiresAt   *time.Time        `json:"expiresAt,omitempty"`
 }
 
 type AuditLog struct {
@@ -106,10 +176,17 @@ type AuditLogFilter struct {
 	ResourceID   *string            `json:"resourceId,omitempty"`
 	Action       *AuditAction       `json:"action,omitempty"`
 	ActorID      *string            `json:"actorId,omitempty"`
+	ActorEmail   *string            `json:"actorEmail,omitempty"`
 	StartTime    *time.Time         `json:"startTime,omitempty"`
 	EndTime      *time.Time         `json:"endTime,omitempty"`
 }
 
+type AuditLogPage struct {
+	Items     []AuditLog `json:"items"`
+	EndCursor *string    `json:"endCursor,omitempty"`
+	HasMore   bool       `json:"hasMore"`
+}
+
 type AuthPayload struct {
 	Token     string    `json:"token"`
 	User      *User     `json:"user"`
@@ -129,35 +206,69 @@ type BudgetAlert struct {
 }
 
 type BudgetPolicy struct {
-	Enabled           bool                 `json:"enabled"`
-	DailyLimitUsd     float64              `json:"dailyLimitUSD"`
-	WeeklyLimitUsd    float64              `json:"weeklyLimitUSD"`
-	MonthlyLimitUsd   float64              `json:"monthlyLimitUSD"`
-	MaxCostPerRequest float64              `json:"maxCostPerRequest"`
-	AlertThreshold    float64              `json:"alertThreshold"`
-	CriticalThreshold float64              `json:"criticalThreshold"`
-	AlertWebhook      string               `json:"alertWebhook"`
-	AlertEmails       []string             `json:"alertEmails"`
-	AlertSlack        string               `json:"alertSlack"`
-	OnExceeded        BudgetExceededAction `json:"onExceeded"`
-	SoftLimitEnabled  bool                 `json:"softLimitEnabled"`
-	SoftLimitBuffer   float64              `json:"softLimitBuffer"`
+	Enabled                  bool                 `json:"enabled"`
+	DailyLimitUsd            float64              `json:"dailyLimitUSD"`
+	WeeklyLimitUsd           float64              `json:"weeklyLimitUSD"`
+	MonthlyLimitUsd          float64              `json:"monthlyLimitUSD"`
+	MaxCostPerRequest        float64              `json:"maxCostPerRequest"`
+	MaxTokensPerConversation int                  `json:"maxTokensPerConversation"`
+	MaxCostPerConversation   float64              `json:"maxCostPerConversation"`
+	AlertThreshold           float64              `json:"alertThreshold"`
+	CriticalThreshold        float64              `json:"criticalThreshold"`
+	AlertWebhook             string               `json:"alertWebhook"`
+	AlertEmails              []string             `json:"alertEmails"`
+	AlertSlack               string               `json:"alertSlack"`
+	OnExceeded               BudgetExceededAction `json:"onExceeded"`
+	SoftLimitEnabled         bool                 `json:"softLimitEnabled"`
+	SoftLimitBuffer          float64              `json:"softLimitBuffer"`
 }
 
 type BudgetPolicyInput struct {
-	Enabled           *bool                 `json:"enabled,omitempty"`
-	DailyLimitUsd     *float64              `json:"dailyLimitUSD,omitempty"`
-	WeeklyLimitUsd    *float64              `json:"weeklyLimitUSD,omitempty"`
-	MonthlyLimitUsd   *float64              `json:"monthlyLimitUSD,omitempty"`
-	MaxCostPerRequest *float64              `json:"maxCostPerRequest,omitempty"`
-	AlertThreshold    *float64              `json:"alertThreshold,omitempty"`
-	CriticalThreshold *float64              `json:"criticalThreshold,omitempty"`
-	AlertWebhook      *string               `json:"alertWebhook,omitempty"`
-	AlertEmails       []string              `json:"alertEmails,omitempty"`
-	AlertSlack        *string               `json:"alertSlack,omitempty"`
-	OnExceeded        *BudgetExceededAction `json:"onExceeded,omitempty"`
-	SoftLimitEnabled  *bool                 `json:"softLimitEnabled,omitempty"`
-	SoftLimitBuffer   *float64              `json:"softLimitBuffer,omitempty"`
+	Enabled                  *bool                 `json:"enabled,omitempty"`
+	DailyLimitUsd            *float64              `json:"dailyLimitUSD,omitempty"`
+	WeeklyLimitUsd           *float64              `json:"weeklyLimitUSD,omitempty"`
+	MonthlyLimitUsd          *float64              `json:"monthlyLimitUSD,omitempty"`
+	MaxCostPerRequest        *float64              `json:"maxCostPerRequest,omitempty"`
+	MaxTokensPerConversation *int                  `json:"maxTokensPerConversation,omitempty"`
+	MaxCostPerConversation   *float64              `json:"maxCostPerConversation,omitempty"`
+	AlertThreshold           *float64              `json:"alertThreshold,omitempty"`
+	CriticalThreshold        *float64              `json:"criticalThreshold,omitempty"`
+	AlertWebhook             *string               `json:"alertWebhook,omitempty"`
+	AlertEmails              []string              `json:"alertEmails,omitempty"`
+	AlertSlack               *string               `json:"alertSlack,omitempty"`
+	OnExceeded               *BudgetExceededAction `json:"onExceeded,omitempty"`
+	SoftLimitEnabled         *bool                 `json:"softLimitEnabled,omitempty"`
+	SoftLimitBuffer          *float64              `json:"softLimitBuffer,omitempty"`
+}
+
+type CacheEntry struct {
+	ID           string     `json:"id"`
+	RoleID       *string    `json:"roleId,omitempty"`
+	Model        string     `json:"model"`
+	Provider     *string    `json:"provider,omitempty"`
+	Prompt       string     `json:"prompt"`
+	InputTokens  int        `json:"inputTokens"`
+	OutputTokens int        `json:"outputTokens"`
+	CostUsd      float64    `json:"costUSD"`
+	HitCount     int        `json:"hitCount"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	ExpiresAt    time.Time  `json:"expiresAt"`
+	LastHitAt    *time.Time `json:"lastHitAt,omitempty"`
+}
+
+type CacheEntryConnection struct {
+	Items      []CacheEntry `json:"items"`
+	TotalCount int          `json:"totalCount"`
+	HasMore    bool         `json:"hasMore"`
+}
+
+type CacheEntryFilter struct {
+	RoleID           *string  `json:"roleId,omitempty"`
+	Model            *string  `json:"model,omitempty"`
+	OlderThanSeconds *int     `json:"olderThanSeconds,omitempty"`
+	PromptContains   *string  `json:"promptContains,omitempty"`
+	SimilarTo        *string  `json:"similarTo,omitempty"`
+	MinSimilarity    *float64 `json:"minSimilarity,omitempty"`
 }
 
 type CacheMetrics struct {
@@ -180,6 +291,7 @@ type CachingPolicy struct {
 	ExcludedModels      []string `json:"excludedModels"`
 	ExcludedPatterns    []string `json:"excludedPatterns"`
 	TrackSavings        bool     `json:"trackSavings"`
+	AllowBypass         bool     `json:"allowBypass"`
 }
 
 type CachingPolicyInput struct {
@@ -192,6 +304,7 @@ type CachingPolicyInput struct {
 	ExcludedModels      []string `json:"excludedModels,omitempty"`
 	ExcludedPatterns    []string `json:"excludedPatterns,omitempty"`
 	TrackSavings        *bool    `json:"trackSavings,omitempty"`
+	AllowBypass         *bool    `json:"allowBypass,omitempty"`
 }
 
 type CapabilityRoutingConfig struct {
@@ -202,12 +315,55 @@ type CapabilityRoutingConfigInput struct {
 	TaskModels []TaskModelMappingInput `json:"taskModels,omitempty"`
 }
 
+type ChangePasswordInput struct {
+	Email           string  `json:"email"`
+	CurrentPassword string  `json:"currentPassword"`
+	NewPassword     string  `json:"newPassword"`
+	Code            *string `json:"code,omitempty"`
+}
+
 type CircuitBreakerInfo struct {
 	Provider string `json:"provider"`
 	State    string `json:"state"`
 	Failures int    `json:"failures"`
 }
 
+type ConcurrencyPolicy struct {
+	Enabled              bool `json:"enabled"`
+	Priority             int  `json:"priority"`
+	Weight               int  `json:"weight"`
+	MaxConcurrentPerKey  int  `json:"maxConcurrentPerKey"`
+	MaxConcurrentPerRole int  `json:"maxConcurrentPerRole"`
+	QueueWhenLimited     bool `json:"queueWhenLimited"`
+}
+
+type ConcurrencyPolicyInput struct {
+	Enabled              *bool `json:"enabled,omitempty"`
+	Priority             *int  `json:"priority,omitempty"`
+	Weight               *int  `json:"weight,omitempty"`
+	MaxConcurrentPerKey  *int  `json:"maxConcurrentPerKey,omitempty"`
+	MaxConcurrentPerRole *int  `json:"maxConcurrentPerRole,omitempty"`
+	QueueWhenLimited     *bool `json:"queueWhenLimited,omitempty"`
+}
+
+type ConfigReloadResult struct {
+	Changed         []string  `json:"changed"`
+	RestartRequired []string  `json:"restartRequired"`
+	ReloadedAt      time.Time `json:"reloadedAt"`
+}
+
+type ConfigSyncChange struct {
+	Kind   string   `json:"kind"`
+	Name   string   `json:"name"`
+	Action string   `json:"action"`
+	Fields []string `json:"fields"`
+}
+
+type ConfigSyncResult struct {
+	DryRun  bool               `json:"dryRun"`
+	Changes []ConfigSyncChange `json:"changes"`
+}
+
 type ConnectionSettings struct {
 	MaxConnections     int  `json:"maxConnections"`
 	MaxIdleConnections int  `json:"maxIdleConnections"`
@@ -242,6 +398,24 @@ type ContentFilteringInput struct {
 	OnDetection           *DetectionAction `json:"onDetection,omitempty"`
 }
 
+type ContextWindowConfig struct {
+	Enabled             bool            `json:"enabled"`
+	Strategy            ContextStrategy `json:"strategy"`
+	ReserveOutputTokens int             `json:"reserveOutputTokens"`
+	KeepRecentMessages  int             `json:"keepRecentMessages"`
+	SummaryModel        string          `json:"summaryModel"`
+	LargerModels        []string        `json:"largerModels"`
+}
+
+type ContextWindowInput struct {
+	Enabled             *bool            `json:"enabled,omitempty"`
+	Strategy            *ContextStrategy `json:"strategy,omitempty"`
+	ReserveOutputTokens *int             `json:"reserveOutputTokens,omitempty"`
+	KeepRecentMessages  *int             `json:"keepRecentMessages,omitempty"`
+	SummaryModel        *string          `json:"summaryModel,omitempty"`
+	LargerModels        []string         `json:"largerModels,omitempty"`
+}
+
 type CostAnalysis struct {
 	TotalCost            float64        `json:"totalCost"`
 	PeriodStart          time.Time      `json:"periodStart"`
@@ -270,10 +444,12 @@ type CostRoutingConfigInput struct {
 }
 
 type CreateAPIKeyInput struct {
-	Name      string     `json:"name"`
-	RoleID    *string    `json:"roleId,omitempty"`
-	GroupID   *string    `json:"groupId,omitempty"`
-	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	Name         string     `json:"name"`
+	RoleID       *string    `json:"roleId,omitempty"`
+	GroupID      *string    `json:"groupId,omitempty"`
+	ExpiresAt    *time.Time `json:"expiresAt,omitempty"`
+	AllowedCidrs []string   `json:"allowedCidrs,omitempty"`
+	ProjectID    *string    `json:"projectId,omitempty"`
 }
 
 type CreateBudgetAlertInput struct {
@@ -305,6 +481,17 @@ type CreateMCPServerInput struct {
 	SyncIntervalMinutes *int                `json:"syncIntervalMinutes,omitempty"`
 }
 
+type CreateMyAPIKeyInput struct {
+	Name      string     `json:"name"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+type CreateProjectInput struct {
+	Name         string             `json:"name"`
+	Description  *string            `json:"description,omitempty"`
+	BudgetPolicy *BudgetPolicyInput `json:"budgetPolicy,omitempty"`
+}
+
 type CreateRegistrationRequestInput struct {
 	OrganizationName  string      `json:"organizationName"`
 	OrganizationEmail string      `json:"organizationEmail"`
@@ -318,6 +505,7 @@ type CreateRoleInput struct {
 	Name        string           `json:"name"`
 	Description *string          `json:"description,omitempty"`
 	IsDefault   *bool            `json:"isDefault,omitempty"`
+	Permissions []string         `json:"permissions,omitempty"`
 	Policy      *RolePolicyInput `json:"policy,omitempty"`
 }
 
@@ -332,6 +520,37 @@ type CreateTenantInput struct {
 	PlanLimitsOverride *PlanLimitsInput `json:"planLimitsOverride,omitempty"`
 }
 
+type CustomDomain struct {
+	ID            string     `json:"id"`
+	Hostname      string     `json:"hostname"`
+	TenantSlug    string     `json:"tenantSlug"`
+	CertSource    CertSource `json:"certSource"`
+	CertExpiresAt *time.Time `json:"certExpiresAt,omitempty"`
+	Enabled       bool       `json:"enabled"`
+	CreatedBy     *string    `json:"createdBy,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+}
+
+type CustomModel struct {
+	ID                string    `json:"id"`
+	ModelID           string    `json:"modelId"`
+	Name              string    `json:"name"`
+	BaseURL           string    `json:"baseUrl"`
+	UpstreamModel     *string   `json:"upstreamModel,omitempty"`
+	ContextWindow     int       `json:"contextWindow"`
+	MaxOutputTokens   int       `json:"maxOutputTokens"`
+	InputCostPer1m    float64   `json:"inputCostPer1M"`
+	OutputCostPer1m   float64   `json:"outputCostPer1M"`
+	SupportsTools     bool      `json:"supportsTools"`
+	SupportsVision    bool      `json:"supportsVision"`
+	SupportsReasoning bool      `json:"supportsReasoning"`
+	SupportsStreaming bool      `json:"supportsStreaming"`
+	Enabled           bool      `json:"enabled"`
+	CreatedAt         time.Time `json:"createdAt"`
+	UpdatedAt         time.Time `json:"updatedAt"`
+}
+
 type DailyCost struct {
 	Date string  `json:"date"`
 	Cost float64 `json:"cost"`
@@ -348,6 +567,75 @@ type DashboardStats struct {
 	TopModels         []ModelUsage    `json:"topModels"`
 	ProviderBreakdown []ProviderUsage `json:"providerBreakdown"`
 	APIKeyBreakdown   []APIKeyUsage   `json:"apiKeyBreakdown"`
+	UserBreakdown     []UserUsage     `json:"userBreakdown"`
+	FeedbackByModel   []FeedbackStats `json:"feedbackByModel"`
+	FeedbackByRole    []FeedbackStats `json:"feedbackByRole"`
+}
+
+type DataPlaneAuditEvent struct {
+	ID              string    `json:"id"`
+	CreatedAt       time.Time `json:"createdAt"`
+	RequestID       *string   `json:"requestId,omitempty"`
+	Method          string    `json:"method"`
+	Path            string    `json:"path"`
+	StatusCode      int       `json:"statusCode"`
+	LatencyMs       int       `json:"latencyMs"`
+	APIKeyID        *string   `json:"apiKeyId,omitempty"`
+	RoleID          *string   `json:"roleId,omitempty"`
+	ProjectID       *string   `json:"projectId,omitempty"`
+	ClientIP        *string   `json:"clientIp,omitempty"`
+	Model           *string   `json:"model,omitempty"`
+	ServedModel     *string   `json:"servedModel,omitempty"`
+	PolicyDecision  *string   `json:"policyDecision,omitempty"`
+	PolicyCode      *string   `json:"policyCode,omitempty"`
+	ToolsAllowed    []string  `json:"toolsAllowed"`
+	ToolsRemoved    []string  `json:"toolsRemoved"`
+	ParamsClamped   []string  `json:"paramsClamped"`
+	CacheStatus     *string   `json:"cacheStatus,omitempty"`
+	RoutingStrategy *string   `json:"routingStrategy,omitempty"`
+}
+
+type DataPlaneAuditFilter struct {
+	APIKeyID       *string    `json:"apiKeyId,omitempty"`
+	Model          *string    `json:"model,omitempty"`
+	PolicyDecision *string    `json:"policyDecision,omitempty"`
+	StartDate      *time.Time `json:"startDate,omitempty"`
+	EndDate        *time.Time `json:"endDate,omitempty"`
+}
+
+type DataPlaneAuditSettings struct {
+	Enabled       bool       `json:"enabled"`
+	StoreEvents   bool       `json:"storeEvents"`
+	WebhookURL    *string    `json:"webhookUrl,omitempty"`
+	DroppedEvents int        `json:"droppedEvents"`
+	UpdatedBy     *string    `json:"updatedBy,omitempty"`
+	UpdatedAt     *time.Time `json:"updatedAt,omitempty"`
+}
+
+type DataPlaneAuditSettingsInput struct {
+	Enabled     bool    `json:"enabled"`
+	StoreEvents bool    `json:"storeEvents"`
+	WebhookURL  *string `json:"webhookUrl,omitempty"`
+}
+
+type DenyMCPToolInput struct {
+	ApprovalID string `json:"approvalId"`
+	Reason     string `json:"reason"`
+}
+
+type DigestSubscription struct {
+	Frequency    DigestFrequency `json:"frequency"`
+	RoleID       *string         `json:"roleId,omitempty"`
+	LastSentAt   *time.Time      `json:"lastSentAt,omitempty"`
+	EmailEnabled bool            `json:"emailEnabled"`
+}
+
+type DisableTrafficInput struct {
+	Provider         Provider   `json:"provider"`
+	Model            *string    `json:"model,omitempty"`
+	Reason           string     `json:"reason"`
+	ExpiresInMinutes *int       `json:"expiresInMinutes,omitempty"`
+	ExpiresAt        *time.Time `json:"expiresAt,omitempty"`
 }
 
 type DiscoveredTool struct {
@@ -378,6 +666,106 @@ type DiscoveredToolFilter struct {
 	RoleID   *string               `json:"roleId,omitempty"`
 }
 
+type DryRunMessageInput struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type DryRunToolInput struct {
+	Name        string         `json:"name"`
+	Description *string        `json:"description,omitempty"`
+	Parameters  map[string]any `json:"parameters,omitempty"`
+}
+
+type EmbedderInput struct {
+	Feature    EmbedderFeature `json:"feature"`
+	Type       string          `json:"type"`
+	Model      string          `json:"model"`
+	BaseURL    *string         `json:"baseUrl,omitempty"`
+	APIKey     *string         `json:"apiKey,omitempty"`
+	Dimensions *int            `json:"dimensions,omitempty"`
+}
+
+type EmbedderSettings struct {
+	Feature    EmbedderFeature `json:"feature"`
+	Type       string          `json:"type"`
+	Model      string          `json:"model"`
+	BaseURL    *string         `json:"baseUrl,omitempty"`
+	HasAPIKey  bool            `json:"hasApiKey"`
+	Dimensions *int            `json:"dimensions,omitempty"`
+	FromConfig bool            `json:"fromConfig"`
+	UpdatedBy  *string         `json:"updatedBy,omitempty"`
+	UpdatedAt  *time.Time      `json:"updatedAt,omitempty"`
+}
+
+type Experiment struct {
+	ID          string              `json:"id"`
+	Name        string              `json:"name"`
+	Description string              `json:"description"`
+	Status      ExperimentStatus    `json:"status"`
+	Model       *string             `json:"model,omitempty"`
+	RoleIds     []string            `json:"roleIds"`
+	APIKeyIds   []string            `json:"apiKeyIds"`
+	Variants    []ExperimentVariant `json:"variants"`
+	CreatedBy   *string             `json:"createdBy,omitempty"`
+	CreatedAt   time.Time           `json:"createdAt"`
+	UpdatedAt   time.Time           `json:"updatedAt"`
+	StartedAt   *time.Time          `json:"startedAt,omitempty"`
+	EndedAt     *time.Time          `json:"endedAt,omitempty"`
+}
+
+type ExperimentInput struct {
+	Name        string                   `json:"name"`
+	Description *string                  `json:"description,omitempty"`
+	Model       *string                  `json:"model,omitempty"`
+	RoleIds     []string                 `json:"roleIds,omitempty"`
+	APIKeyIds   []string                 `json:"apiKeyIds,omitempty"`
+	Variants    []ExperimentVariantInput `json:"variants"`
+}
+
+type ExperimentResults struct {
+	Experiment *Experiment              `json:"experiment"`
+	From       time.Time                `json:"from"`
+	To         time.Time                `json:"to"`
+	Variants   []ExperimentVariantStats `json:"variants"`
+}
+
+type ExperimentVariant struct {
+	Name             string           `json:"name"`
+	Weight           int              `json:"weight"`
+	Model            *string          `json:"model,omitempty"`
+	SystemPrompt     *string          `json:"systemPrompt,omitempty"`
+	SystemPromptMode SystemPromptMode `json:"systemPromptMode"`
+}
+
+type ExperimentVariantInput struct {
+	Name             string            `json:"name"`
+	Weight           int               `json:"weight"`
+	Model            *string           `json:"model,omitempty"`
+	SystemPrompt     *string           `json:"systemPrompt,omitempty"`
+	SystemPromptMode *SystemPromptMode `json:"systemPromptMode,omitempty"`
+}
+
+type ExperimentVariantStats struct {
+	Variant               string  `json:"variant"`
+	Weight                int     `json:"weight"`
+	Requests              int     `json:"requests"`
+	Share                 float64 `json:"share"`
+	SuccessRate           float64 `json:"successRate"`
+	AvgLatencyMs          float64 `json:"avgLatencyMs"`
+	P50LatencyMs          float64 `json:"p50LatencyMs"`
+	P95LatencyMs          float64 `json:"p95LatencyMs"`
+	AvgTimeToFirstTokenMs float64 `json:"avgTimeToFirstTokenMs"`
+	AvgInputTokens        float64 `json:"avgInputTokens"`
+	AvgOutputTokens       float64 `json:"avgOutputTokens"`
+	TotalCostUsd          float64 `json:"totalCostUsd"`
+	CostPerRequest        float64 `json:"costPerRequest"`
+	Feedback              int     `json:"feedback"`
+	ThumbsUp              int     `json:"thumbsUp"`
+	ThumbsDown            int     `json:"thumbsDown"`
+	AvgRating             float64 `json:"avgRating"`
+}
+
 type FallbackConfig struct {
 	Provider  string `json:"provider"`
 	Model     string `json:"model"`
@@ -392,6 +780,118 @@ type FallbackConfigInput struct {
 	TimeoutMs *int   `json:"timeoutMs,omitempty"`
 }
 
+type FeatureFlag struct {
+	Key            string                    `json:"key"`
+	Description    string                    `json:"description"`
+	Enabled        bool                      `json:"enabled"`
+	DefaultEnabled bool                      `json:"defaultEnabled"`
+	RoleOverrides  []FeatureFlagRoleOverride `json:"roleOverrides"`
+	UpdatedBy      *string                   `json:"updatedBy,omitempty"`
+	UpdatedAt      *time.Time                `json:"updatedAt,omitempty"`
+}
+
+type FeatureFlagChange struct {
+	ID              string    `json:"id"`
+	FlagKey         string    `json:"flagKey"`
+	RoleID          *string   `json:"roleId,omitempty"`
+	RoleName        *string   `json:"roleName,omitempty"`
+	Enabled         *bool     `json:"enabled,omitempty"`
+	PreviousEnabled *bool     `json:"previousEnabled,omitempty"`
+	Note            *string   `json:"note,omitempty"`
+	ActorEmail      *string   `json:"actorEmail,omitempty"`
+	CreatedAt       time.Time `json:"createdAt"`
+}
+
+type FeatureFlagChangelogFilter struct {
+	FlagKey   *string    `json:"flagKey,omitempty"`
+	StartDate *time.Time `json:"startDate,omitempty"`
+	EndDate   *time.Time `json:"endDate,omitempty"`
+}
+
+type FeatureFlagRoleOverride struct {
+	RoleID   string  `json:"roleId"`
+	RoleName *string `json:"roleName,omitempty"`
+	Enabled  bool    `json:"enabled"`
+}
+
+type Feedback struct {
+	ID            string    `json:"id"`
+	RequestID     string    `json:"requestId"`
+	UsageRecordID string    `json:"usageRecordId"`
+	Model         string    `json:"model"`
+	Provider      string    `json:"provider"`
+	RoleID        *string   `json:"roleId,omitempty"`
+	APIKeyID      *string   `json:"apiKeyId,omitempty"`
+	Thumbs        *Thumbs   `json:"thumbs,omitempty"`
+	Rating        *int      `json:"rating,omitempty"`
+	Comment       *string   `json:"comment,omitempty"`
+	SubmittedBy   *string   `json:"submittedBy,omitempty"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+type FeedbackFilter struct {
+	From   *time.Time `json:"from,omitempty"`
+	To     *time.Time `json:"to,omitempty"`
+	Model  *string    `json:"model,omitempty"`
+	RoleID *string    `json:"roleId,omitempty"`
+	Thumbs *Thumbs    `json:"thumbs,omitempty"`
+}
+
+type FeedbackInput struct {
+	ResponseID string  `json:"responseId"`
+	Thumbs     *Thumbs `json:"thumbs,omitempty"`
+	Rating     *int    `json:"rating,omitempty"`
+	Comment    *string `json:"comment,omitempty"`
+}
+
+type FeedbackStats struct {
+	Key        string  `json:"key"`
+	Name       string  `json:"name"`
+	Count      int     `json:"count"`
+	ThumbsUp   int     `json:"thumbsUp"`
+	ThumbsDown int     `json:"thumbsDown"`
+	Rated      int     `json:"rated"`
+	AvgRating  float64 `json:"avgRating"`
+	Comments   int     `json:"comments"`
+}
+
+type FilePolicy struct {
+	Enabled          bool     `json:"enabled"`
+	MaxFileSize      int      `json:"maxFileSize"`
+	AllowedMimeTypes []string `json:"allowedMimeTypes"`
+	RetentionDays    int      `json:"retentionDays"`
+}
+
+type FilePolicyInput struct {
+	Enabled          *bool    `json:"enabled,omitempty"`
+	MaxFileSize      *int     `json:"maxFileSize,omitempty"`
+	AllowedMimeTypes []string `json:"allowedMimeTypes,omitempty"`
+	RetentionDays    *int     `json:"retentionDays,omitempty"`
+}
+
+type GenerationPolicy struct {
+	Enabled            bool     `json:"enabled"`
+	DefaultTemperature *float64 `json:"defaultTemperature,omitempty"`
+	MinTemperature     *float64 `json:"minTemperature,omitempty"`
+	MaxTemperature     *float64 `json:"maxTemperature,omitempty"`
+	DefaultTopP        *float64 `json:"defaultTopP,omitempty"`
+	MaxTopP            *float64 `json:"maxTopP,omitempty"`
+	DefaultMaxTokens   int      `json:"defaultMaxTokens"`
+	MaxTokensLimit     int      `json:"maxTokensLimit"`
+}
+
+type GenerationPolicyInput struct {
+	Enabled            *bool    `json:"enabled,omitempty"`
+	DefaultTemperature *float64 `json:"defaultTemperature,omitempty"`
+	MinTemperature     *float64 `json:"minTemperature,omitempty"`
+	MaxTemperature     *float64 `json:"maxTemperature,omitempty"`
+	DefaultTopP        *float64 `json:"defaultTopP,omitempty"`
+	MaxTopP            *float64 `json:"maxTopP,omitempty"`
+	DefaultMaxTokens   *int     `json:"defaultMaxTokens,omitempty"`
+	MaxTokensLimit     *int     `json:"maxTokensLimit,omitempty"`
+}
+
 type Group struct {
 	ID             string    `json:"id"`
 	Name           string    `json:"name"`
@@ -403,12 +903,52 @@ type Group struct {
 	UpdatedAt      time.Time `json:"updatedAt"`
 }
 
+type HedgingConfig struct {
+	Enabled  bool   `json:"enabled"`
+	DelayMs  int    `json:"delayMs"`
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+}
+
+type HedgingInput struct {
+	Enabled  *bool   `json:"enabled,omitempty"`
+	DelayMs  *int    `json:"delayMs,omitempty"`
+	Provider *string `json:"provider,omitempty"`
+	Model    *string `json:"model,omitempty"`
+}
+
 type HourlyStats struct {
 	Hour     string `json:"hour"`
 	Requests int    `json:"requests"`
 	Tokens   int    `json:"tokens"`
 }
 
+type HuggingFaceEndpoint struct {
+	Name              string  `json:"name"`
+	URL               string  `json:"url"`
+	Model             string  `json:"model"`
+	HourlyCostUsd     float64 `json:"hourlyCostUsd"`
+	ContextWindow     int     `json:"contextWindow"`
+	MaxOutputTokens   int     `json:"maxOutputTokens"`
+	SupportsTools     bool    `json:"supportsTools"`
+	SupportsVision    bool    `json:"supportsVision"`
+	SupportsStreaming bool    `json:"supportsStreaming"`
+	Enabled           bool    `json:"enabled"`
+}
+
+type HuggingFaceEndpointInput struct {
+	Name              string   `json:"name"`
+	URL               string   `json:"url"`
+	Model             *string  `json:"model,omitempty"`
+	HourlyCostUsd     *float64 `json:"hourlyCostUsd,omitempty"`
+	ContextWindow     *int     `json:"contextWindow,omitempty"`
+	MaxOutputTokens   *int     `json:"maxOutputTokens,omitempty"`
+	SupportsTools     *bool    `json:"supportsTools,omitempty"`
+	SupportsVision    *bool    `json:"supportsVision,omitempty"`
+	SupportsStreaming *bool    `json:"supportsStreaming,omitempty"`
+	Enabled           *bool    `json:"enabled,omitempty"`
+}
+
 type InjectionDetectionConfig struct {
 	Enabled          bool                    `json:"enabled"`
 	DetectionMethod  DetectionMethod         `json:"detectionMethod"`
@@ -429,6 +969,21 @@ type InjectionDetectionInput struct {
 	MlDetection      *MLDetectionInput      `json:"mlDetection,omitempty"`
 }
 
+type InjectionMatch struct {
+	Pattern    *InjectionPattern `json:"pattern"`
+	Similarity float64           `json:"similarity"`
+}
+
+type InjectionPattern struct {
+	ID        string            `json:"id"`
+	Text      string            `json:"text"`
+	Category  InjectionCategory `json:"category"`
+	Builtin   bool              `json:"builtin"`
+	Embedded  bool              `json:"embedded"`
+	CreatedBy *string           `json:"createdBy,omitempty"`
+	CreatedAt time.Time         `json:"createdAt"`
+}
+
 type InputBoundsConfig struct {
 	Enabled             bool    `json:"enabled"`
 	MaxPromptLength     int     `json:"maxPromptLength"`
@@ -457,19 +1012,48 @@ type InputBoundsInput struct {
 	AnomalyThreshold    *float64 `json:"anomalyThreshold,omitempty"`
 }
 
+type KillSwitch struct {
+	Provider   Provider   `json:"provider"`
+	Model      *string    `json:"model,omitempty"`
+	Target     string     `json:"target"`
+	Reason     string     `json:"reason"`
+	DisabledBy *string    `json:"disabledBy,omitempty"`
+	DisabledAt time.Time  `json:"disabledAt"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+}
+
 type LatencyRoutingConfig struct {
-	MaxLatencyMs    int      `json:"maxLatencyMs"`
-	PreferredModels []string `json:"preferredModels"`
+	MaxLatencyMs      int      `json:"maxLatencyMs"`
+	PreferredModels   []string `json:"preferredModels"`
+	Percentile        string   `json:"percentile"`
+	HysteresisPercent int      `json:"hysteresisPercent"`
+	MaxErrorRate      float64  `json:"maxErrorRate"`
 }
 
 type LatencyRoutingConfigInput struct {
-	MaxLatencyMs    *int     `json:"maxLatencyMs,omitempty"`
-	PreferredModels []string `json:"preferredModels,omitempty"`
+	MaxLatencyMs      *int     `json:"maxLatencyMs,omitempty"`
+	PreferredModels   []string `json:"preferredModels,omitempty"`
+	Percentile        *string  `json:"percentile,omitempty"`
+	HysteresisPercent *int     `json:"hysteresisPercent,omitempty"`
+	MaxErrorRate      *float64 `json:"maxErrorRate,omitempty"`
+}
+
+type LiteLLMImportResult struct {
+	DryRun   bool                `json:"dryRun"`
+	Changes  []ConfigSyncChange  `json:"changes"`
+	Aliases  []LiteLLMModelAlias `json:"aliases"`
+	Warnings []string            `json:"warnings"`
+}
+
+type LiteLLMModelAlias struct {
+	Name  string `json:"name"`
+	Model string `json:"model"`
 }
 
 type LoginInput struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email    string  `json:"email"`
+	Password string  `json:"password"`
+	Code     *string `json:"code,omitempty"`
 }
 
 type MCPAuthConfigInput struct {
@@ -490,15 +1074,27 @@ type MCPAuthConfigInput struct {
 }
 
 type MCPPolicies struct {
-	Enabled            bool `json:"enabled"`
-	AllowToolSearch    bool `json:"allowToolSearch"`
-	AuditToolExecution bool `json:"auditToolExecution"`
+	Enabled                 bool           `json:"enabled"`
+	AllowToolSearch         bool           `json:"allowToolSearch"`
+	AuditToolExecution      bool           `json:"auditToolExecution"`
+	ListDeniedTools         bool           `json:"listDeniedTools"`
+	MaxInvocationsPerMinute int            `json:"maxInvocationsPerMinute"`
+	MaxInvocationsPerDay    int            `json:"maxInvocationsPerDay"`
+	DailyBudgetUsd          float64        `json:"dailyBudgetUsd"`
+	MonthlyBudgetUsd        float64        `json:"monthlyBudgetUsd"`
+	ToolLimits              []MCPToolLimit `json:"toolLimits"`
 }
 
 type MCPPoliciesInput struct {
-	Enabled            *bool `json:"enabled,omitempty"`
-	AllowToolSearch    *bool `json:"allowToolSearch,omitempty"`
-	AuditToolExecution *bool `json:"auditToolExecution,omitempty"`
+	Enabled                 *bool               `json:"enabled,omitempty"`
+	AllowToolSearch         *bool               `json:"allowToolSearch,omitempty"`
+	AuditToolExecution      *bool               `json:"auditToolExecution,omitempty"`
+	ListDeniedTools         *bool               `json:"listDeniedTools,omitempty"`
+	MaxInvocationsPerMinute *int                `json:"maxInvocationsPerMinute,omitempty"`
+	MaxInvocationsPerDay    *int                `json:"maxInvocationsPerDay,omitempty"`
+	DailyBudgetUsd          *float64            `json:"dailyBudgetUsd,omitempty"`
+	MonthlyBudgetUsd        *float64            `json:"monthlyBudgetUsd,omitempty"`
+	ToolLimits              []MCPToolLimitInput `json:"toolLimits,omitempty"`
 }
 
 type MCPSchemaChange struct {
@@ -578,21 +1174,102 @@ type MCPTool struct {
 	Visibility         MCPToolVisibility `json:"visibility"`
 }
 
+type MCPToolAnalytics struct {
+	PeriodStart     time.Time               `json:"periodStart"`
+	PeriodEnd       time.Time               `json:"periodEnd"`
+	Totals          *MCPToolExecutionStats  `json:"totals"`
+	ByTool          []MCPToolExecutionStats `json:"byTool"`
+	ByServer        []MCPToolExecutionStats `json:"byServer"`
+	ByRole          []MCPToolExecutionStats `json:"byRole"`
+	TimeSeries      []MCPToolExecutionPoint `json:"timeSeries"`
+	TopFailingTools []MCPToolExecutionStats `json:"topFailingTools"`
+}
+
+type MCPToolApproval struct {
+	ID               string             `json:"id"`
+	RoleID           string             `json:"roleId"`
+	RoleName         string             `json:"roleName"`
+	ServerID         string             `json:"serverId"`
+	ServerName       string             `json:"serverName"`
+	ToolID           string             `json:"toolId"`
+	ToolName         string             `json:"toolName"`
+	Status           string             `json:"status"`
+	RequestedBy      *string            `json:"requestedBy,omitempty"`
+	RequestCount     int                `json:"requestCount"`
+	FirstRequestedAt time.Time          `json:"firstRequestedAt"`
+	LastRequestedAt  time.Time          `json:"lastRequestedAt"`
+	Visibility       *MCPToolVisibility `json:"visibility,omitempty"`
+	DecidedByEmail   *string            `json:"decidedByEmail,omitempty"`
+	DecidedAt        *time.Time         `json:"decidedAt,omitempty"`
+	DecisionReason   *string            `json:"decisionReason,omitempty"`
+}
+
 type MCPToolExecution struct {
 	ID           string         `json:"id"`
 	ServerID     string         `json:"serverId"`
 	ToolID       string         `json:"toolId"`
+	ServerName   *string        `json:"serverName,omitempty"`
+	ToolName     *string        `json:"toolName,omitempty"`
 	RoleID       *string        `json:"roleId,omitempty"`
+	APIKeyID     *string        `json:"apiKeyId,omitempty"`
 	RequestID    *string        `json:"requestId,omitempty"`
 	InputParams  map[string]any `json:"inputParams,omitempty"`
 	OutputResult map[string]any `json:"outputResult,omitempty"`
 	Status       string         `json:"status"`
 	ErrorMessage *string        `json:"errorMessage,omitempty"`
+	CostUsd      float64        `json:"costUsd"`
 	StartedAt    time.Time      `json:"startedAt"`
 	CompletedAt  *time.Time     `json:"completedAt,omitempty"`
 	DurationMs   *int           `json:"durationMs,omitempty"`
 }
 
+type MCPToolExecutionConnection struct {
+	Items      []MCPToolExecution `json:"items"`
+	TotalCount int                `json:"totalCount"`
+	HasMore    bool               `json:"hasMore"`
+}
+
+type MCPToolExecutionFilter struct {
+	ServerID  *string    `json:"serverId,omitempty"`
+	ToolID    *string    `json:"toolId,omitempty"`
+	RoleID    *string    `json:"roleId,omitempty"`
+	APIKeyID  *string    `json:"apiKeyId,omitempty"`
+	Status    *string    `json:"status,omitempty"`
+	StartDate *time.Time `json:"startDate,omitempty"`
+	EndDate   *time.Time `json:"endDate,omitempty"`
+}
+
+type MCPToolExecutionPoint struct {
+	Timestamp     time.Time `json:"timestamp"`
+	Executions    int       `json:"executions"`
+	Errors        int       `json:"errors"`
+	P95DurationMs float64   `json:"p95DurationMs"`
+}
+
+type MCPToolExecutionStats struct {
+	ID            string  `json:"id"`
+	Name          string  `json:"name"`
+	Executions    int     `json:"executions"`
+	Errors        int     `json:"errors"`
+	Blocked       int     `json:"blocked"`
+	ErrorRate     float64 `json:"errorRate"`
+	AvgDurationMs float64 `json:"avgDurationMs"`
+	P95DurationMs float64 `json:"p95DurationMs"`
+	CostUsd       float64 `json:"costUsd"`
+}
+
+type MCPToolLimit struct {
+	Tool            string  `json:"tool"`
+	CooldownSeconds int     `json:"cooldownSeconds"`
+	CostPerCallUsd  float64 `json:"costPerCallUsd"`
+}
+
+type MCPToolLimitInput struct {
+	Tool            string   `json:"tool"`
+	CooldownSeconds *int     `json:"cooldownSeconds,omitempty"`
+	CostPerCallUsd  *float64 `json:"costPerCallUsd,omitempty"`
+}
+
 type MCPToolPermission struct {
 	ID             string            `json:"id"`
 	RoleID         string            `json:"roleId"`
@@ -630,15 +1307,19 @@ type MLDetectionInput struct {
 }
 
 type Model struct {
-	ID                string   `json:"id"`
-	Name              string   `json:"name"`
-	Provider          Provider `json:"provider"`
-	Enabled           bool     `json:"enabled"`
-	SupportsTools     bool     `json:"supportsTools"`
-	SupportsStreaming bool     `json:"supportsStreaming"`
-	ContextLimit      int      `json:"contextLimit"`
-	InputCostPer1m    float64  `json:"inputCostPer1M"`
-	OutputCostPer1m   float64  `json:"outputCostPer1M"`
+	ID                   string   `json:"id"`
+	Name                 string   `json:"name"`
+	Provider             Provider `json:"provider"`
+	Enabled              bool     `json:"enabled"`
+	SupportsTools        bool     `json:"supportsTools"`
+	SupportsVision       bool     `json:"supportsVision"`
+	SupportsReasoning    bool     `json:"supportsReasoning"`
+	SupportsStreaming    bool     `json:"supportsStreaming"`
+	ContextLimit         int      `json:"contextLimit"`
+	OutputLimit          int      `json:"outputLimit"`
+	InputCostPer1m       float64  `json:"inputCostPer1M"`
+	OutputCostPer1m      float64  `json:"outputCostPer1M"`
+	CachedInputCostPer1m float64  `json:"cachedInputCostPer1M"`
 }
 
 type ModelCost struct {
@@ -647,11 +1328,50 @@ type ModelCost struct {
 	Requests int     `json:"requests"`
 }
 
+type ModelDeprecation struct {
+	Provider         Provider   `json:"provider"`
+	ModelID          string     `json:"modelId"`
+	SunsetAt         time.Time  `json:"sunsetAt"`
+	ReplacementModel *string    `json:"replacementModel,omitempty"`
+	Notice           *string    `json:"notice,omitempty"`
+	Retired          bool       `json:"retired"`
+	AlertedAt        *time.Time `json:"alertedAt,omitempty"`
+}
+
+type ModelFilterInput struct {
+	Provider           *Provider `json:"provider,omitempty"`
+	SupportsTools      *bool     `json:"supportsTools,omitempty"`
+	SupportsVision     *bool     `json:"supportsVision,omitempty"`
+	SupportsReasoning  *bool     `json:"supportsReasoning,omitempty"`
+	MinContext         *int      `json:"minContext,omitempty"`
+	MaxInputCostPer1m  *float64  `json:"maxInputCostPer1M,omitempty"`
+	MaxOutputCostPer1m *float64  `json:"maxOutputCostPer1M,omitempty"`
+}
+
 type ModelPerformance struct {
-	Model        string  `json:"model"`
-	AvgLatencyMs float64 `json:"avgLatencyMs"`
-	SuccessRate  float64 `json:"successRate"`
-	RequestCount int     `json:"requestCount"`
+	Model                 string   `json:"model"`
+	Provider              Provider `json:"provider"`
+	AvgLatencyMs          float64  `json:"avgLatencyMs"`
+	P95LatencyMs          float64  `json:"p95LatencyMs"`
+	SuccessRate           float64  `json:"successRate"`
+	RequestCount          int      `json:"requestCount"`
+	StreamedRequestCount  int      `json:"streamedRequestCount"`
+	AvgTimeToFirstTokenMs float64  `json:"avgTimeToFirstTokenMs"`
+	P95TimeToFirstTokenMs float64  `json:"p95TimeToFirstTokenMs"`
+	AvgTokensPerSecond    float64  `json:"avgTokensPerSecond"`
+}
+
+type ModelPrice struct {
+	ID              *string     `json:"id,omitempty"`
+	Provider        Provider    `json:"provider"`
+	ModelID         string      `json:"modelId"`
+	InputCostPer1m  float64     `json:"inputCostPer1M"`
+	OutputCostPer1m float64     `json:"outputCostPer1M"`
+	Source          PriceSource `json:"source"`
+	Reset           bool        `json:"reset"`
+	EffectiveFrom   *time.Time  `json:"effectiveFrom,omitempty"`
+	Note            *string     `json:"note,omitempty"`
+	CreatedBy       *string     `json:"createdBy,omitempty"`
 }
 
 type ModelRateLimit struct {
@@ -669,17 +1389,25 @@ type ModelRateLimitInput struct {
 }
 
 type ModelRestrictions struct {
-	AllowedModels       []string   `json:"allowedModels"`
-	AllowedProviders    []Provider `json:"allowedProviders"`
-	DefaultModel        string     `json:"defaultModel"`
-	MaxTokensPerRequest int        `json:"maxTokensPerRequest"`
+	AllowedModels           []string   `json:"allowedModels"`
+	AllowedProviders        []Provider `json:"allowedProviders"`
+	DefaultModel            string     `json:"defaultModel"`
+	MaxTokensPerRequest     int        `json:"maxTokensPerRequest"`
+	RewriteDeprecatedModels bool       `json:"rewriteDeprecatedModels"`
+	AllowedEmbeddingModels  []string   `json:"allowedEmbeddingModels"`
+	MaxEmbeddingDimensions  int        `json:"maxEmbeddingDimensions"`
+	MaxEmbeddingInputs      int        `json:"maxEmbeddingInputs"`
 }
 
 type ModelRestrictionsInput struct {
-	AllowedModels       []string   `json:"allowedModels,omitempty"`
-	AllowedProviders    []Provider `json:"allowedProviders,omitempty"`
-	DefaultModel        *string    `json:"defaultModel,omitempty"`
-	MaxTokensPerRequest *int       `json:"maxTokensPerRequest,omitempty"`
+	AllowedModels           []string   `json:"allowedModels,omitempty"`
+	AllowedProviders        []Provider `json:"allowedProviders,omitempty"`
+	DefaultModel            *string    `json:"defaultModel,omitempty"`
+	MaxTokensPerRequest     *int       `json:"maxTokensPerRequest,omitempty"`
+	RewriteDeprecatedModels *bool      `json:"rewriteDeprecatedModels,omitempty"`
+	AllowedEmbeddingModels  []string   `json:"allowedEmbeddingModels,omitempty"`
+	MaxEmbeddingDimensions  *int       `json:"maxEmbeddingDimensions,omitempty"`
+	MaxEmbeddingInputs      *int       `json:"maxEmbeddingInputs,omitempty"`
 }
 
 type ModelSwitch struct {
@@ -706,6 +1434,18 @@ type ModelUsage struct {
 type Mutation struct {
 }
 
+type NetworkPolicy struct {
+	Enabled           bool     `json:"enabled"`
+	AllowedCidrs      []string `json:"allowedCidrs"`
+	RequireClientCert bool     `json:"requireClientCert"`
+}
+
+type NetworkPolicyInput struct {
+	Enabled           *bool    `json:"enabled,omitempty"`
+	AllowedCidrs      []string `json:"allowedCidrs,omitempty"`
+	RequireClientCert *bool    `json:"requireClientCert,omitempty"`
+}
+
 type NormalizationConfig struct {
 	Enabled                  bool            `json:"enabled"`
 	UnicodeNormalization     UnicodeNormForm `json:"unicodeNormalization"`
@@ -734,6 +1474,62 @@ type NormalizationInput struct {
 	TrimWhitespace           *bool            `json:"trimWhitespace,omitempty"`
 }
 
+type OllamaModel struct {
+	Name              string     `json:"name"`
+	SizeBytes         int        `json:"sizeBytes"`
+	Digest            string     `json:"digest"`
+	Family            *string    `json:"family,omitempty"`
+	ParameterSize     *string    `json:"parameterSize,omitempty"`
+	QuantizationLevel *string    `json:"quantizationLevel,omitempty"`
+	ModifiedAt        *time.Time `json:"modifiedAt,omitempty"`
+	Loaded            bool       `json:"loaded"`
+	VramBytes         int        `json:"vramBytes"`
+	ExpiresAt         *time.Time `json:"expiresAt,omitempty"`
+}
+
+type OllamaPull struct {
+	Model          string           `json:"model"`
+	Status         OllamaPullStatus `json:"status"`
+	Detail         *string          `json:"detail,omitempty"`
+	CompletedBytes int              `json:"completedBytes"`
+	TotalBytes     int              `json:"totalBytes"`
+	Error          *string          `json:"error,omitempty"`
+	StartedAt      time.Time        `json:"startedAt"`
+	FinishedAt     *time.Time       `json:"finishedAt,omitempty"`
+}
+
+type OutputRewriteRule struct {
+	Name        *string `json:"name,omitempty"`
+	Pattern     string  `json:"pattern"`
+	Replacement string  `json:"replacement"`
+}
+
+type OutputRewriteRuleInput struct {
+	Name        *string `json:"name,omitempty"`
+	Pattern     string  `json:"pattern"`
+	Replacement string  `json:"replacement"`
+}
+
+type OutputTransformPolicy struct {
+	Enabled          bool                `json:"enabled"`
+	StripReasoning   bool                `json:"stripReasoning"`
+	StripCitations   bool                `json:"stripCitations"`
+	StripMarkdown    bool                `json:"stripMarkdown"`
+	Rewrites         []OutputRewriteRule `json:"rewrites"`
+	MaxLength        int                 `json:"maxLength"`
+	TruncationSuffix string              `json:"truncationSuffix"`
+}
+
+type OutputTransformPolicyInput struct {
+	Enabled          *bool                    `json:"enabled,omitempty"`
+	StripReasoning   *bool                    `json:"stripReasoning,omitempty"`
+	StripCitations   *bool                    `json:"stripCitations,omitempty"`
+	StripMarkdown    *bool                    `json:"stripMarkdown,omitempty"`
+	Rewrites         []OutputRewriteRuleInput `json:"rewrites,omitempty"`
+	MaxLength        *int                     `json:"maxLength,omitempty"`
+	TruncationSuffix *string                  `json:"truncationSuffix,omitempty"`
+}
+
 type OutputValidationConfig struct {
 	Enabled                   bool                  `json:"enabled"`
 	EnforceSchema             bool                  `json:"enforceSchema"`
@@ -751,6 +1547,7 @@ type OutputValidationConfig struct {
 	DetectPIILeakage          bool                  `json:"detectPIILeakage"`
 	DetectSystemPromptLeakage bool                  `json:"detectSystemPromptLeakage"`
 	ApplyContentFiltering     bool                  `json:"applyContentFiltering"`
+	StreamWindowChars         int                   `json:"streamWindowChars"`
 	OnViolation               OutputViolationAction `json:"onViolation"`
 }
 
@@ -771,6 +1568,7 @@ type OutputValidationInput struct {
 	DetectPIILeakage          *bool                  `json:"detectPIILeakage,omitempty"`
 	DetectSystemPromptLeakage *bool                  `json:"detectSystemPromptLeakage,omitempty"`
 	ApplyContentFiltering     *bool                  `json:"applyContentFiltering,omitempty"`
+	StreamWindowChars         *int                   `json:"streamWindowChars,omitempty"`
 	OnViolation               *OutputViolationAction `json:"onViolation,omitempty"`
 }
 
@@ -881,6 +1679,38 @@ type PlanLimitsInput struct {
 	MaxProviders              *int `json:"maxProviders,omitempty"`
 }
 
+type PolicyDryRunCheck struct {
+	Role    *string `json:"role,omitempty"`
+	Policy  string  `json:"policy"`
+	Outcome string  `json:"outcome"`
+	Code    *string `json:"code,omitempty"`
+	Message *string `json:"message,omitempty"`
+}
+
+type PolicyDryRunInput struct {
+	Model       string               `json:"model"`
+	Messages    []DryRunMessageInput `json:"messages"`
+	Tools       []DryRunToolInput    `json:"tools,omitempty"`
+	MaxTokens   *int                 `json:"maxTokens,omitempty"`
+	Temperature *float64             `json:"temperature,omitempty"`
+	TopP        *float64             `json:"topP,omitempty"`
+	APIKeyID    *string              `json:"apiKeyId,omitempty"`
+	RoleID      *string              `json:"roleId,omitempty"`
+}
+
+type PolicyDryRunResult struct {
+	Allowed      bool                `json:"allowed"`
+	Checks       []PolicyDryRunCheck `json:"checks"`
+	Tools        []PolicyDryRunTool  `json:"tools"`
+	RemovedTools []string            `json:"removedTools"`
+}
+
+type PolicyDryRunTool struct {
+	Name   string  `json:"name"`
+	Status string  `json:"status"`
+	Reason *string `json:"reason,omitempty"`
+}
+
 type PolicyViolationRecord struct {
 	ID            string    `json:"id"`
 	APIKeyID      *string   `json:"apiKeyId,omitempty"`
@@ -899,6 +1729,34 @@ type PolicyViolationSummary struct {
 	AvgSeverity   float64 `json:"avgSeverity"`
 }
 
+type Project struct {
+	ID             string        `json:"id"`
+	Name           string        `json:"name"`
+	Description    string        `json:"description"`
+	BudgetPolicy   *BudgetPolicy `json:"budgetPolicy"`
+	Spend          *ProjectSpend `json:"spend"`
+	APIKeyCount    int           `json:"apiKeyCount"`
+	CreatedBy      *string       `json:"createdBy,omitempty"`
+	CreatedByEmail *string       `json:"createdByEmail,omitempty"`
+	CreatedAt      time.Time     `json:"createdAt"`
+	UpdatedAt      time.Time     `json:"updatedAt"`
+}
+
+type ProjectSpend struct {
+	DailyUsd   float64 `json:"dailyUSD"`
+	WeeklyUsd  float64 `json:"weeklyUSD"`
+	MonthlyUsd float64 `json:"monthlyUSD"`
+}
+
+type ProjectUsage struct {
+	ProjectID   string  `json:"projectId"`
+	ProjectName string  `json:"projectName"`
+	Requests    int     `json:"requests"`
+	Tokens      int     `json:"tokens"`
+	Cost        float64 `json:"cost"`
+	Percentage  float64 `json:"percentage"`
+}
+
 type PromptPolicies struct {
 	StructuralSeparation       *StructuralSeparationConfig   `json:"structuralSeparation"`
 	Normalization              *NormalizationConfig          `json:"normalization"`
@@ -924,39 +1782,58 @@ type PromptPoliciesInput struct {
 }
 
 type ProviderAPIKey struct {
-	ID                 string     `json:"id"`
-	Provider           Provider   `json:"provider"`
-	Name               *string    `json:"name,omitempty"`
-	KeyPrefix          string     `json:"keyPrefix"`
-	CredentialType     string     `json:"credentialType"`
-	Priority           int        `json:"priority"`
-	Enabled            bool       `json:"enabled"`
-	HealthScore        float64    `json:"healthScore"`
-	SuccessCount       int        `json:"successCount"`
-	FailureCount       int        `json:"failureCount"`
-	RateLimitRemaining *int       `json:"rateLimitRemaining,omitempty"`
-	RateLimitResetAt   *time.Time `json:"rateLimitResetAt,omitempty"`
-	RequestCount       int        `json:"requestCount"`
-	LastUsedAt         *time.Time `json:"lastUsedAt,omitempty"`
-	CreatedAt          time.Time  `json:"createdAt"`
-	UpdatedAt          time.Time  `json:"updatedAt"`
+	ID                      string     `json:"id"`
+	Provider                Provider   `json:"provider"`
+	Name                    *string    `json:"name,omitempty"`
+	KeyPrefix               string     `json:"keyPrefix"`
+	CredentialType          string     `json:"credentialType"`
+	Priority                int        `json:"priority"`
+	Enabled                 bool       `json:"enabled"`
+	HealthScore             float64    `json:"healthScore"`
+	SuccessCount            int        `json:"successCount"`
+	FailureCount            int        `json:"failureCount"`
+	RateLimitRemaining      *int       `json:"rateLimitRemaining,omitempty"`
+	RateLimitResetAt        *time.Time `json:"rateLimitResetAt,omitempty"`
+	RequestCount            int        `json:"requestCount"`
+	LastUsedAt              *time.Time `json:"lastUsedAt,omitempty"`
+	CreatedAt               time.Time  `json:"createdAt"`
+	UpdatedAt               time.Time  `json:"updatedAt"`
+	TrafficPercent          *int       `json:"trafficPercent,omitempty"`
+	RollbackErrorRate       float64    `json:"rollbackErrorRate"`
+	RolledBackAt            *time.Time `json:"rolledBackAt,omitempty"`
+	RollbackReason          *string    `json:"rollbackReason,omitempty"`
+	RecentRequests          int        `json:"recentRequests"`
+	RecentErrorRate         float64    `json:"recentErrorRate"`
+	LastValidatedAt         *time.Time `json:"lastValidatedAt,omitempty"`
+	LastValidationOk        *bool      `json:"lastValidationOk,omitempty"`
+	LastValidationError     *string    `json:"lastValidationError,omitempty"`
+	LastValidationLatencyMs *int       `json:"lastValidationLatencyMs,omitempty"`
+	CooldownUntil           *time.Time `json:"cooldownUntil,omitempty"`
+	CooldownReason          *string    `json:"cooldownReason,omitempty"`
+	InputTokens             int        `json:"inputTokens"`
+	OutputTokens            int        `json:"outputTokens"`
+	CostUsd                 float64    `json:"costUSD"`
 }
 
 type ProviderConfig struct {
-	Provider           Provider            `json:"provider"`
-	Enabled            bool                `json:"enabled"`
-	HasAPIKey          bool                `json:"hasApiKey"`
-	HasAccessKeys      bool                `json:"hasAccessKeys"`
-	StreamingMode      *string             `json:"streamingMode,omitempty"`
-	BaseURL            *string             `json:"baseUrl,omitempty"`
-	Region             *string             `json:"region,omitempty"`
-	RegionPrefix       *string             `json:"regionPrefix,omitempty"`
-	ResourceName       *string             `json:"resourceName,omitempty"`
-	APIVersion         *string             `json:"apiVersion,omitempty"`
-	ModelsURL          *string             `json:"modelsUrl,omitempty"`
-	ConnectionSettings *ConnectionSettings `json:"connectionSettings"`
-	PlanCeiling        *ConnectionSettings `json:"planCeiling"`
-	APIKeys            []ProviderAPIKey    `json:"apiKeys"`
+	Provider             Provider              `json:"provider"`
+	Enabled              bool                  `json:"enabled"`
+	HasAPIKey            bool                  `json:"hasApiKey"`
+	HasAccessKeys        bool                  `json:"hasAccessKeys"`
+	StreamingMode        *string               `json:"streamingMode,omitempty"`
+	BaseURL              *string               `json:"baseUrl,omitempty"`
+	Region               *string               `json:"region,omitempty"`
+	RegionPrefix         *string               `json:"regionPrefix,omitempty"`
+	ResourceName         *string               `json:"resourceName,omitempty"`
+	APIVersion           *string               `json:"apiVersion,omitempty"`
+	ModelsURL            *string               `json:"modelsUrl,omitempty"`
+	ConnectionSettings   *ConnectionSettings   `json:"connectionSettings"`
+	PlanCeiling          *ConnectionSettings   `json:"planCeiling"`
+	Throttles            []ProviderThrottle    `json:"throttles"`
+	Regions              []ProviderRegion      `json:"regions"`
+	KeyStrategy          KeyStrategy           `json:"keyStrategy"`
+	APIKeys              []ProviderAPIKey      `json:"apiKeys"`
+	HuggingFaceEndpoints []HuggingFaceEndpoint `json:"huggingFaceEndpoints"`
 }
 
 type ProviderCost struct {
@@ -978,6 +1855,35 @@ type ProviderHealthMetrics struct {
 	Providers []ProviderHealthInfo `json:"providers"`
 }
 
+type ProviderHealthSample struct {
+	BucketStart        time.Time `json:"bucketStart"`
+	BucketSeconds      int       `json:"bucketSeconds"`
+	Provider           string    `json:"provider"`
+	Model              string    `json:"model"`
+	Requests           int       `json:"requests"`
+	Failures           int       `json:"failures"`
+	ClientErrors       int       `json:"clientErrors"`
+	SuccessRate        float64   `json:"successRate"`
+	P50LatencyMs       float64   `json:"p50LatencyMs"`
+	P95LatencyMs       float64   `json:"p95LatencyMs"`
+	P99LatencyMs       float64   `json:"p99LatencyMs"`
+	BreakerState       string    `json:"breakerState"`
+	BreakerOpenSeconds float64   `json:"breakerOpenSeconds"`
+}
+
+type ProviderKeyValidation struct {
+	KeyID        string    `json:"keyId"`
+	Valid        bool      `json:"valid"`
+	Method       string    `json:"method"`
+	Model        *string   `json:"model,omitempty"`
+	LatencyMs    int       `json:"latencyMs"`
+	ModelCount   *int      `json:"modelCount,omitempty"`
+	Capabilities []string  `json:"capabilities"`
+	ErrorCode    *string   `json:"errorCode,omitempty"`
+	Error        *string   `json:"error,omitempty"`
+	CheckedAt    time.Time `json:"checkedAt"`
+}
+
 type ProviderModelUsage struct {
 	Provider     string  `json:"provider"`
 	Model        string  `json:"model"`
@@ -986,6 +1892,60 @@ type ProviderModelUsage struct {
 	CostUsd      float64 `json:"costUsd"`
 }
 
+type ProviderRegion struct {
+	Name             string     `json:"name"`
+	Priority         int        `json:"priority"`
+	Enabled          bool       `json:"enabled"`
+	BaseURL          *string    `json:"baseUrl,omitempty"`
+	Region           *string    `json:"region,omitempty"`
+	RegionPrefix     *string    `json:"regionPrefix,omitempty"`
+	APIKeyName       *string    `json:"apiKeyName,omitempty"`
+	FailedOver       bool       `json:"failedOver"`
+	FailedOverReason *string    `json:"failedOverReason,omitempty"`
+	FailedOverUntil  *time.Time `json:"failedOverUntil,omitempty"`
+}
+
+type ProviderRegionInput struct {
+	Name         string  `json:"name"`
+	Priority     *int    `json:"priority,omitempty"`
+	Enabled      *bool   `json:"enabled,omitempty"`
+	BaseURL      *string `json:"baseUrl,omitempty"`
+	Region       *string `json:"region,omitempty"`
+	RegionPrefix *string `json:"regionPrefix,omitempty"`
+	APIKeyName   *string `json:"apiKeyName,omitempty"`
+}
+
+type ProviderSLAReport struct {
+	Month               time.Time `json:"month"`
+	Provider            string    `json:"provider"`
+	Model               string    `json:"model"`
+	Requests            int       `json:"requests"`
+	Failures            int       `json:"failures"`
+	AvailabilityPercent float64   `json:"availabilityPercent"`
+	UptimePercent       float64   `json:"uptimePercent"`
+	DowntimeMinutes     float64   `json:"downtimeMinutes"`
+	BreakerOpenMinutes  float64   `json:"breakerOpenMinutes"`
+	P50LatencyMs        float64   `json:"p50LatencyMs"`
+	P95LatencyMs        float64   `json:"p95LatencyMs"`
+	P99LatencyMs        float64   `json:"p99LatencyMs"`
+	TargetPercent       float64   `json:"targetPercent"`
+	TargetMet           bool      `json:"targetMet"`
+}
+
+type ProviderThrottle struct {
+	Model           *string `json:"model,omitempty"`
+	MaxConcurrent   int     `json:"maxConcurrent"`
+	TokensPerMinute int     `json:"tokensPerMinute"`
+	MaxQueueWaitSec int     `json:"maxQueueWaitSec"`
+}
+
+type ProviderThrottleInput struct {
+	Model           *string `json:"model,omitempty"`
+	MaxConcurrent   *int    `json:"maxConcurrent,omitempty"`
+	TokensPerMinute *int    `json:"tokensPerMinute,omitempty"`
+	MaxQueueWaitSec *int    `json:"maxQueueWaitSec,omitempty"`
+}
+
 type ProviderUsage struct {
 	Provider   Provider `json:"provider"`
 	Requests   int      `json:"requests"`
@@ -1005,6 +1965,28 @@ type ProviderWeightInput struct {
 type Query struct {
 }
 
+type QuotaLimits struct {
+	RequestsLimit int        `json:"requestsLimit"`
+	TokensLimit   int        `json:"tokensLimit"`
+	CostLimitUsd  float64    `json:"costLimitUSD"`
+	UpdatedBy     *string    `json:"updatedBy,omitempty"`
+	UpdatedAt     *time.Time `json:"updatedAt,omitempty"`
+}
+
+type QuotaPeriod struct {
+	ID            string     `json:"id"`
+	PeriodStart   time.Time  `json:"periodStart"`
+	PeriodEnd     time.Time  `json:"periodEnd"`
+	RequestsUsed  int        `json:"requestsUsed"`
+	RequestsLimit int        `json:"requestsLimit"`
+	TokensUsed    int        `json:"tokensUsed"`
+	TokensLimit   int        `json:"tokensLimit"`
+	CostUsedUsd   float64    `json:"costUsedUSD"`
+	CostLimitUsd  float64    `json:"costLimitUSD"`
+	Closed        bool       `json:"closed"`
+	ClosedAt      *time.Time `json:"closedAt,omitempty"`
+}
+
 type RateLimitPolicy struct {
 	RequestsPerMinute int              `json:"requestsPerMinute"`
 	RequestsPerHour   int              `json:"requestsPerHour"`
@@ -1016,6 +1998,7 @@ type RateLimitPolicy struct {
 	CostPerHourUsd    float64          `json:"costPerHourUSD"`
 	CostPerDayUsd     float64          `json:"costPerDayUSD"`
 	CostPerMonthUsd   float64          `json:"costPerMonthUSD"`
+	BurstMultiplier   float64          `json:"burstMultiplier"`
 	BurstLimit        int              `json:"burstLimit"`
 	BurstTokens       int              `json:"burstTokens"`
 	PerModelLimits    []ModelRateLimit `json:"perModelLimits"`
@@ -1032,6 +2015,7 @@ type RateLimitPolicyInput struct {
 	CostPerHourUsd    *float64              `json:"costPerHourUSD,omitempty"`
 	CostPerDayUsd     *float64              `json:"costPerDayUSD,omitempty"`
 	CostPerMonthUsd   *float64              `json:"costPerMonthUSD,omitempty"`
+	BurstMultiplier   *float64              `json:"burstMultiplier,omitempty"`
 	BurstLimit        *int                  `json:"burstLimit,omitempty"`
 	BurstTokens       *int                  `json:"burstTokens,omitempty"`
 	PerModelLimits    []ModelRateLimitInput `json:"perModelLimits,omitempty"`
@@ -1044,20 +2028,38 @@ type RefreshModelsResult struct {
 	Provider Provider `json:"provider"`
 }
 
+type RegisterCustomModelInput struct {
+	ModelID           string   `json:"modelId"`
+	Name              *string  `json:"name,omitempty"`
+	BaseURL           string   `json:"baseUrl"`
+	UpstreamModel     *string  `json:"upstreamModel,omitempty"`
+	ContextWindow     *int     `json:"contextWindow,omitempty"`
+	MaxOutputTokens   *int     `json:"maxOutputTokens,omitempty"`
+	InputCostPer1m    *float64 `json:"inputCostPer1M,omitempty"`
+	OutputCostPer1m   *float64 `json:"outputCostPer1M,omitempty"`
+	SupportsTools     *bool    `json:"supportsTools,omitempty"`
+	SupportsVision    *bool    `json:"supportsVision,omitempty"`
+	SupportsReasoning *bool    `json:"supportsReasoning,omitempty"`
+	SupportsStreaming *bool    `json:"supportsStreaming,omitempty"`
+	Enabled           *bool    `json:"enabled,omitempty"`
+}
+
 type RegistrationRequest struct {
-	ID                string     `json:"id"`
-	OrganizationName  string     `json:"organizationName"`
-	OrganizationEmail string     `json:"organizationEmail"`
-	AdminName         string     `json:"adminName"`
-	AdminEmail        string     `json:"adminEmail"`
-	Slug              string     `json:"slug"`
-	Status            string     `json:"status"`
-	RejectionReason   *string    `json:"rejectionReason,omitempty"`
-	RequestedAt       time.Time  `json:"requestedAt"`
-	ReviewedAt        *time.Time `json:"reviewedAt,omitempty"`
-	ReviewedBy        *string    `json:"reviewedBy,omitempty"`
-	CreatedAt         time.Time  `json:"createdAt"`
-	UpdatedAt         time.Time  `json:"updatedAt"`
+	ID                string      `json:"id"`
+	OrganizationName  string      `json:"organizationName"`
+	OrganizationEmail string      `json:"organizationEmail"`
+	AdminName         string      `json:"adminName"`
+	AdminEmail        string      `json:"adminEmail"`
+	Slug              string      `json:"slug"`
+	PreferredTier     *TenantTier `json:"preferredTier,omitempty"`
+	Status            string      `json:"status"`
+	RejectionReason   *string     `json:"rejectionReason,omitempty"`
+	UserRole          *string     `json:"userRole,omitempty"`
+	RequestedAt       time.Time   `json:"requestedAt"`
+	ReviewedAt        *time.Time  `json:"reviewedAt,omitempty"`
+	ReviewedBy        *string     `json:"reviewedBy,omitempty"`
+	CreatedAt         time.Time   `json:"createdAt"`
+	UpdatedAt         time.Time   `json:"updatedAt"`
 }
 
 type RejectRegistrationInput struct {
@@ -1065,19 +2067,48 @@ type RejectRegistrationInput struct {
 	Reason    string `json:"reason"`
 }
 
+type ReplayDiffRow struct {
+	Kind  ReplayDiffKind `json:"kind"`
+	Left  *string        `json:"left,omitempty"`
+	Right *string        `json:"right,omitempty"`
+}
+
+type ReplayResult struct {
+	RecordID  string          `json:"recordId"`
+	RequestID string          `json:"requestId"`
+	Original  *ReplaySide     `json:"original"`
+	Replay    *ReplaySide     `json:"replay"`
+	Diff      []ReplayDiffRow `json:"diff"`
+	Identical bool            `json:"identical"`
+}
+
+type ReplaySide struct {
+	Model        string  `json:"model"`
+	Response     *string `json:"response,omitempty"`
+	Success      bool    `json:"success"`
+	Error        *string `json:"error,omitempty"`
+	InputTokens  int     `json:"inputTokens"`
+	OutputTokens int     `json:"outputTokens"`
+	CostUsd      float64 `json:"costUSD"`
+	LatencyMs    int     `json:"latencyMs"`
+}
+
 type RequestLog struct {
-	ID           string    `json:"id"`
-	Model        string    `json:"model"`
-	Provider     Provider  `json:"provider"`
-	Status       string    `json:"status"`
-	InputTokens  int       `json:"inputTokens"`
-	OutputTokens int       `json:"outputTokens"`
-	LatencyMs    int       `json:"latencyMs"`
-	CostUsd      float64   `json:"costUSD"`
-	APIKeyName   *string   `json:"apiKeyName,omitempty"`
-	ErrorCode    *string   `json:"errorCode,omitempty"`
-	ErrorMessage *string   `json:"errorMessage,omitempty"`
-	CreatedAt    time.Time `json:"createdAt"`
+	ID                 string    `json:"id"`
+	Model              string    `json:"model"`
+	Provider           Provider  `json:"provider"`
+	Status             string    `json:"status"`
+	InputTokens        int       `json:"inputTokens"`
+	OutputTokens       int       `json:"outputTokens"`
+	LatencyMs          int       `json:"latencyMs"`
+	CostUsd            float64   `json:"costUSD"`
+	APIKeyName         *string   `json:"apiKeyName,omitempty"`
+	ErrorCode          *string   `json:"errorCode,omitempty"`
+	ErrorMessage       *string   `json:"errorMessage,omitempty"`
+	CancelledByClient  bool      `json:"cancelledByClient"`
+	TimeToFirstTokenMs *int      `json:"timeToFirstTokenMs,omitempty"`
+	TokensPerSecond    *float64  `json:"tokensPerSecond,omitempty"`
+	CreatedAt          time.Time `json:"createdAt"`
 }
 
 type RequestLogConnection struct {
@@ -1087,21 +2118,26 @@ type RequestLogConnection struct {
 }
 
 type RequestLogDetail struct {
-	ID           string         `json:"id"`
-	Model        string         `json:"model"`
-	Provider     Provider       `json:"provider"`
-	Status       string         `json:"status"`
-	InputTokens  int            `json:"inputTokens"`
-	OutputTokens int            `json:"outputTokens"`
-	LatencyMs    int            `json:"latencyMs"`
-	CostUsd      float64        `json:"costUSD"`
-	APIKeyName   *string        `json:"apiKeyName,omitempty"`
-	ErrorCode    *string        `json:"errorCode,omitempty"`
-	ErrorMessage *string        `json:"errorMessage,omitempty"`
-	Prompt       *string        `json:"prompt,omitempty"`
-	Response     *string        `json:"response,omitempty"`
-	Metadata     map[string]any `json:"metadata,omitempty"`
-	CreatedAt    time.Time      `json:"createdAt"`
+	ID                 string         `json:"id"`
+	Model              string         `json:"model"`
+	Provider           Provider       `json:"provider"`
+	Status             string         `json:"status"`
+	InputTokens        int            `json:"inputTokens"`
+	OutputTokens       int            `json:"outputTokens"`
+	LatencyMs          int            `json:"latencyMs"`
+	CostUsd            float64        `json:"costUSD"`
+	APIKeyName         *string        `json:"apiKeyName,omitempty"`
+	ErrorCode          *string        `json:"errorCode,omitempty"`
+	ErrorMessage       *string        `json:"errorMessage,omitempty"`
+	CancelledByClient  bool           `json:"cancelledByClient"`
+	TimeToFirstTokenMs *int           `json:"timeToFirstTokenMs,omitempty"`
+	TokensPerSecond    *float64       `json:"tokensPerSecond,omitempty"`
+	Prompt             *string        `json:"prompt,omitempty"`
+	Response           *string        `json:"response,omitempty"`
+	Metadata           map[string]any `json:"metadata,omitempty"`
+	Replayable         bool           `json:"replayable"`
+	ReplayOf           *string        `json:"replayOf,omitempty"`
+	CreatedAt          time.Time      `json:"createdAt"`
 }
 
 type RequestLogFilter struct {
@@ -1109,6 +2145,7 @@ type RequestLogFilter struct {
 	Provider  *Provider  `json:"provider,omitempty"`
 	Status    *string    `json:"status,omitempty"`
 	APIKeyID  *string    `json:"apiKeyId,omitempty"`
+	ProjectID *string    `json:"projectId,omitempty"`
 	StartDate *time.Time `json:"startDate,omitempty"`
 	EndDate   *time.Time `json:"endDate,omitempty"`
 	Search    *string    `json:"search,omitempty"`
@@ -1122,22 +2159,24 @@ type ResilienceMetrics struct {
 }
 
 type ResiliencePolicy struct {
-	Enabled                 bool             `json:"enabled"`
-	RetryEnabled            bool             `json:"retryEnabled"`
-	MaxRetries              int              `json:"maxRetries"`
-	RetryBackoffMs          int              `json:"retryBackoffMs"`
-	RetryBackoffMax         int              `json:"retryBackoffMax"`
-	RetryJitter             bool             `json:"retryJitter"`
-	RetryOnTimeout          bool             `json:"retryOnTimeout"`
-	RetryOnRateLimit        bool             `json:"retryOnRateLimit"`
-	RetryOnServerError      bool             `json:"retryOnServerError"`
-	RetryableErrors         []string         `json:"retryableErrors"`
-	FallbackEnabled         bool             `json:"fallbackEnabled"`
-	FallbackChain           []FallbackConfig `json:"fallbackChain"`
-	CircuitBreakerEnabled   bool             `json:"circuitBreakerEnabled"`
-	CircuitBreakerThreshold int              `json:"circuitBreakerThreshold"`
-	CircuitBreakerTimeout   int              `json:"circuitBreakerTimeout"`
-	RequestTimeoutMs        int              `json:"requestTimeoutMs"`
+	Enabled                 bool                 `json:"enabled"`
+	RetryEnabled            bool                 `json:"retryEnabled"`
+	MaxRetries              int                  `json:"maxRetries"`
+	RetryBackoffMs          int                  `json:"retryBackoffMs"`
+	RetryBackoffMax         int                  `json:"retryBackoffMax"`
+	RetryJitter             bool                 `json:"retryJitter"`
+	RetryOnTimeout          bool                 `json:"retryOnTimeout"`
+	RetryOnRateLimit        bool                 `json:"retryOnRateLimit"`
+	RetryOnServerError      bool                 `json:"retryOnServerError"`
+	RetryableErrors         []string             `json:"retryableErrors"`
+	FallbackEnabled         bool                 `json:"fallbackEnabled"`
+	FallbackChain           []FallbackConfig     `json:"fallbackChain"`
+	CircuitBreakerEnabled   bool                 `json:"circuitBreakerEnabled"`
+	CircuitBreakerThreshold int                  `json:"circuitBreakerThreshold"`
+	CircuitBreakerTimeout   int                  `json:"circuitBreakerTimeout"`
+	RequestTimeoutMs        int                  `json:"requestTimeoutMs"`
+	ContextWindow           *ContextWindowConfig `json:"contextWindow"`
+	Hedging                 *HedgingConfig       `json:"hedging"`
 }
 
 type ResiliencePolicyInput struct {
@@ -1157,6 +2196,18 @@ type ResiliencePolicyInput struct {
 	CircuitBreakerThreshold *int                  `json:"circuitBreakerThreshold,omitempty"`
 	CircuitBreakerTimeout   *int                  `json:"circuitBreakerTimeout,omitempty"`
 	RequestTimeoutMs        *int                  `json:"requestTimeoutMs,omitempty"`
+	ContextWindow           *ContextWindowInput   `json:"contextWindow,omitempty"`
+	Hedging                 *HedgingInput         `json:"hedging,omitempty"`
+}
+
+type RetentionPolicy struct {
+	Table               string     `json:"table"`
+	RetentionDays       int        `json:"retentionDays"`
+	ArchiveBeforeDelete bool       `json:"archiveBeforeDelete"`
+	Enabled             bool       `json:"enabled"`
+	LastRunAt           *time.Time `json:"lastRunAt,omitempty"`
+	LastArchivedCount   int        `json:"lastArchivedCount"`
+	LastDeletedCount    int        `json:"lastDeletedCount"`
 }
 
 type RiskAssessment struct {
@@ -1173,6 +2224,7 @@ type Role struct {
 	Description    *string     `json:"description,omitempty"`
 	IsDefault      bool        `json:"isDefault"`
 	IsSystem       bool        `json:"isSystem"`
+	Permissions    []string    `json:"permissions"`
 	Policy         *RolePolicy `json:"policy,omitempty"`
 	CreatedBy      *string     `json:"createdBy,omitempty"`
 	CreatedByEmail *string     `json:"createdByEmail,omitempty"`
@@ -1181,31 +2233,41 @@ type Role struct {
 }
 
 type RolePolicy struct {
-	ID                string             `json:"id"`
-	RoleID            string             `json:"roleId"`
-	PromptPolicies    *PromptPolicies    `json:"promptPolicies"`
-	ToolPolicies      *ToolPolicies      `json:"toolPolicies"`
-	RateLimitPolicy   *RateLimitPolicy   `json:"rateLimitPolicy"`
-	ModelRestrictions *ModelRestrictions `json:"modelRestrictions"`
-	CachingPolicy     *CachingPolicy     `json:"cachingPolicy"`
-	RoutingPolicy     *RoutingPolicy     `json:"routingPolicy"`
-	ResiliencePolicy  *ResiliencePolicy  `json:"resiliencePolicy"`
-	BudgetPolicy      *BudgetPolicy      `json:"budgetPolicy"`
-	McpPolicies       *MCPPolicies       `json:"mcpPolicies"`
-	CreatedAt         time.Time          `json:"createdAt"`
-	UpdatedAt         time.Time          `json:"updatedAt"`
+	ID                    string                 `json:"id"`
+	RoleID                string                 `json:"roleId"`
+	PromptPolicies        *PromptPolicies        `json:"promptPolicies"`
+	ToolPolicies          *ToolPolicies          `json:"toolPolicies"`
+	RateLimitPolicy       *RateLimitPolicy       `json:"rateLimitPolicy"`
+	ModelRestrictions     *ModelRestrictions     `json:"modelRestrictions"`
+	CachingPolicy         *CachingPolicy         `json:"cachingPolicy"`
+	RoutingPolicy         *RoutingPolicy         `json:"routingPolicy"`
+	ResiliencePolicy      *ResiliencePolicy      `json:"resiliencePolicy"`
+	BudgetPolicy          *BudgetPolicy          `json:"budgetPolicy"`
+	ConcurrencyPolicy     *ConcurrencyPolicy     `json:"concurrencyPolicy"`
+	NetworkPolicy         *NetworkPolicy         `json:"networkPolicy"`
+	FilePolicy            *FilePolicy            `json:"filePolicy"`
+	GenerationPolicy      *GenerationPolicy      `json:"generationPolicy"`
+	OutputTransformPolicy *OutputTransformPolicy `json:"outputTransformPolicy"`
+	McpPolicies           *MCPPolicies           `json:"mcpPolicies"`
+	CreatedAt             time.Time              `json:"createdAt"`
+	UpdatedAt             time.Time              `json:"updatedAt"`
 }
 
 type RolePolicyInput struct {
-	PromptPolicies    *PromptPoliciesInput    `json:"promptPolicies,omitempty"`
-	ToolPolicies      *ToolPoliciesInput      `json:"toolPolicies,omitempty"`
-	RateLimitPolicy   *RateLimitPolicyInput   `json:"rateLimitPolicy,omitempty"`
-	ModelRestrictions *ModelRestrictionsInput `json:"modelRestrictions,omitempty"`
-	CachingPolicy     *CachingPolicyInput     `json:"cachingPolicy,omitempty"`
-	RoutingPolicy     *RoutingPolicyInput     `json:"routingPolicy,omitempty"`
-	ResiliencePolicy  *ResiliencePolicyInput  `json:"resiliencePolicy,omitempty"`
-	BudgetPolicy      *BudgetPolicyInput      `json:"budgetPolicy,omitempty"`
-	McpPolicies       *MCPPoliciesInput       `json:"mcpPolicies,omitempty"`
+	PromptPolicies        *PromptPoliciesInput        `json:"promptPolicies,omitempty"`
+	ToolPolicies          *ToolPoliciesInput          `json:"toolPolicies,omitempty"`
+	RateLimitPolicy       *RateLimitPolicyInput       `json:"rateLimitPolicy,omitempty"`
+	ModelRestrictions     *ModelRestrictionsInput     `json:"modelRestrictions,omitempty"`
+	CachingPolicy         *CachingPolicyInput         `json:"cachingPolicy,omitempty"`
+	RoutingPolicy         *RoutingPolicyInput         `json:"routingPolicy,omitempty"`
+	ResiliencePolicy      *ResiliencePolicyInput      `json:"resiliencePolicy,omitempty"`
+	BudgetPolicy          *BudgetPolicyInput          `json:"budgetPolicy,omitempty"`
+	ConcurrencyPolicy     *ConcurrencyPolicyInput     `json:"concurrencyPolicy,omitempty"`
+	NetworkPolicy         *NetworkPolicyInput         `json:"networkPolicy,omitempty"`
+	FilePolicy            *FilePolicyInput            `json:"filePolicy,omitempty"`
+	GenerationPolicy      *GenerationPolicyInput      `json:"generationPolicy,omitempty"`
+	OutputTransformPolicy *OutputTransformPolicyInput `json:"outputTransformPolicy,omitempty"`
+	McpPolicies           *MCPPoliciesInput           `json:"mcpPolicies,omitempty"`
 }
 
 type RoutingMetrics struct {
@@ -1223,6 +2285,7 @@ type RoutingPolicy struct {
 	WeightedConfig     *WeightedRoutingConfig   `json:"weightedConfig,omitempty"`
 	CapabilityConfig   *CapabilityRoutingConfig `json:"capabilityConfig,omitempty"`
 	AllowModelOverride bool                     `json:"allowModelOverride"`
+	CapabilityFallback bool                     `json:"capabilityFallback"`
 }
 
 type RoutingPolicyInput struct {
@@ -1233,6 +2296,14 @@ type RoutingPolicyInput struct {
 	WeightedConfig     *WeightedRoutingConfigInput   `json:"weightedConfig,omitempty"`
 	CapabilityConfig   *CapabilityRoutingConfigInput `json:"capabilityConfig,omitempty"`
 	AllowModelOverride *bool                         `json:"allowModelOverride,omitempty"`
+	CapabilityFallback *bool                         `json:"capabilityFallback,omitempty"`
+}
+
+type SetFeatureFlagInput struct {
+	Key     string  `json:"key"`
+	RoleID  *string `json:"roleId,omitempty"`
+	Enabled *bool   `json:"enabled,omitempty"`
+	Note    *string `json:"note,omitempty"`
 }
 
 type SetMCPPermissionInput struct {
@@ -1243,6 +2314,29 @@ type SetMCPPermissionInput struct {
 	Reason     *string           `json:"reason,omitempty"`
 }
 
+type SetModelDeprecationInput struct {
+	Provider         Provider  `json:"provider"`
+	ModelID          string    `json:"modelId"`
+	SunsetAt         time.Time `json:"sunsetAt"`
+	ReplacementModel *string   `json:"replacementModel,omitempty"`
+	Notice           *string   `json:"notice,omitempty"`
+}
+
+type SetModelPriceOverrideInput struct {
+	Provider        Provider   `json:"provider"`
+	ModelID         string     `json:"modelId"`
+	InputCostPer1m  float64    `json:"inputCostPer1M"`
+	OutputCostPer1m float64    `json:"outputCostPer1M"`
+	EffectiveFrom   *time.Time `json:"effectiveFrom,omitempty"`
+	Note            *string    `json:"note,omitempty"`
+}
+
+type SetQuotaLimitsInput struct {
+	RequestsLimit int     `json:"requestsLimit"`
+	TokensLimit   int     `json:"tokensLimit"`
+	CostLimitUsd  float64 `json:"costLimitUSD"`
+}
+
 type SetToolPermissionInput struct {
 	ToolID string               `json:"toolId"`
 	RoleID string               `json:"roleId"`
@@ -1255,6 +2349,25 @@ type SetToolPermissionsBulkInput struct {
 	Permissions []ToolPermissionEntry `json:"permissions"`
 }
 
+type SpendForecast struct {
+	ID           string     `json:"id"`
+	Name         string     `json:"name"`
+	RoleID       *string    `json:"roleId,omitempty"`
+	SpentUsd     float64    `json:"spentUSD"`
+	DailyBurnUsd float64    `json:"dailyBurnUSD"`
+	ProjectedUsd float64    `json:"projectedUSD"`
+	BudgetUsd    float64    `json:"budgetUSD"`
+	OverBudget   bool       `json:"overBudget"`
+	ExhaustedAt  *time.Time `json:"exhaustedAt,omitempty"`
+}
+
+type SpendForecastReport struct {
+	PeriodStart time.Time       `json:"periodStart"`
+	PeriodEnd   time.Time       `json:"periodEnd"`
+	APIKeys     []SpendForecast `json:"apiKeys"`
+	Roles       []SpendForecast `json:"roles"`
+}
+
 type StrategyCount struct {
 	Strategy string `json:"strategy"`
 	Count    int    `json:"count"`
@@ -1495,10 +2608,36 @@ type ToolWithPermission struct {
 	DecisionReason *string              `json:"decisionReason,omitempty"`
 }
 
+type TwoFactorCredentialsInput struct {
+	Email    string  `json:"email"`
+	Password string  `json:"password"`
+	Code     *string `json:"code,omitempty"`
+}
+
+type TwoFactorEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioningUri"`
+	QRCode          string `json:"qrCode"`
+}
+
+type TwoFactorSettings struct {
+	RequireForAdmins bool       `json:"requireForAdmins"`
+	RequireForAll    bool       `json:"requireForAll"`
+	UpdatedBy        *string    `json:"updatedBy,omitempty"`
+	UpdatedAt        *time.Time `json:"updatedAt,omitempty"`
+}
+
+type TwoFactorSettingsInput struct {
+	RequireForAdmins bool `json:"requireForAdmins"`
+	RequireForAll    bool `json:"requireForAll"`
+}
+
 type UpdateAPIKeyInput struct {
-	Name    *string `json:"name,omitempty"`
-	RoleID  *string `json:"roleId,omitempty"`
-	GroupID *string `json:"groupId,omitempty"`
+	Name         *string  `json:"name,omitempty"`
+	RoleID       *string  `json:"roleId,omitempty"`
+	GroupID      *string  `json:"groupId,omitempty"`
+	AllowedCidrs []string `json:"allowedCidrs,omitempty"`
+	ProjectID    *string  `json:"projectId,omitempty"`
 }
 
 type UpdateBudgetAlertInput struct {
@@ -1507,6 +2646,35 @@ type UpdateBudgetAlertInput struct {
 	Enabled   *bool    `json:"enabled,omitempty"`
 }
 
+type UpdateCustomDomainInput struct {
+	TenantSlug     *string `json:"tenantSlug,omitempty"`
+	Enabled        *bool   `json:"enabled,omitempty"`
+	CertificatePem *string `json:"certificatePem,omitempty"`
+	PrivateKeyPem  *string `json:"privateKeyPem,omitempty"`
+	UseAcme        *bool   `json:"useAcme,omitempty"`
+}
+
+type UpdateCustomModelInput struct {
+	ModelID           *string  `json:"modelId,omitempty"`
+	Name              *string  `json:"name,omitempty"`
+	BaseURL           *string  `json:"baseUrl,omitempty"`
+	UpstreamModel     *string  `json:"upstreamModel,omitempty"`
+	ContextWindow     *int     `json:"contextWindow,omitempty"`
+	MaxOutputTokens   *int     `json:"maxOutputTokens,omitempty"`
+	InputCostPer1m    *float64 `json:"inputCostPer1M,omitempty"`
+	OutputCostPer1m   *float64 `json:"outputCostPer1M,omitempty"`
+	SupportsTools     *bool    `json:"supportsTools,omitempty"`
+	SupportsVision    *bool    `json:"supportsVision,omitempty"`
+	SupportsReasoning *bool    `json:"supportsReasoning,omitempty"`
+	SupportsStreaming *bool    `json:"supportsStreaming,omitempty"`
+	Enabled           *bool    `json:"enabled,omitempty"`
+}
+
+type UpdateDigestSubscriptionInput struct {
+	Frequency DigestFrequency `json:"frequency"`
+	RoleID    *string         `json:"roleId,omitempty"`
+}
+
 type UpdateGroupInput struct {
 	Name        *string  `json:"name,omitempty"`
 	Description *string  `json:"description,omitempty"`
@@ -1524,6 +2692,12 @@ type UpdateMCPServerInput struct {
 	SyncIntervalMinutes *int                `json:"syncIntervalMinutes,omitempty"`
 }
 
+type UpdateProjectInput struct {
+	Name         *string            `json:"name,omitempty"`
+	Description  *string            `json:"description,omitempty"`
+	BudgetPolicy *BudgetPolicyInput `json:"budgetPolicy,omitempty"`
+}
+
 type UpdateProviderAPIKeyInput struct {
 	ID              string  `json:"id"`
 	Name            *string `json:"name,omitempty"`
@@ -1535,24 +2709,36 @@ type UpdateProviderAPIKeyInput struct {
 }
 
 type UpdateProviderInput struct {
-	Provider           Provider                 `json:"provider"`
-	Enabled            bool                     `json:"enabled"`
-	APIKey             *string                  `json:"apiKey,omitempty"`
-	BaseURL            *string                  `json:"baseUrl,omitempty"`
-	Region             *string                  `json:"region,omitempty"`
-	RegionPrefix       *string                  `json:"regionPrefix,omitempty"`
-	AccessKeyID        *string                  `json:"accessKeyId,omitempty"`
-	SecretAccessKey    *string                  `json:"secretAccessKey,omitempty"`
-	ResourceName       *string                  `json:"resourceName,omitempty"`
-	APIVersion         *string                  `json:"apiVersion,omitempty"`
-	ModelsURL          *string                  `json:"modelsUrl,omitempty"`
-	ConnectionSettings *ConnectionSettingsInput `json:"connectionSettings,omitempty"`
+	Provider             Provider                   `json:"provider"`
+	Enabled              bool                       `json:"enabled"`
+	APIKey               *string                    `json:"apiKey,omitempty"`
+	BaseURL              *string                    `json:"baseUrl,omitempty"`
+	Region               *string                    `json:"region,omitempty"`
+	RegionPrefix         *string                    `json:"regionPrefix,omitempty"`
+	AccessKeyID          *string                    `json:"accessKeyId,omitempty"`
+	SecretAccessKey      *string                    `json:"secretAccessKey,omitempty"`
+	ResourceName         *string                    `json:"resourceName,omitempty"`
+	APIVersion           *string                    `json:"apiVersion,omitempty"`
+	ModelsURL            *string                    `json:"modelsUrl,omitempty"`
+	ConnectionSettings   *ConnectionSettingsInput   `json:"connectionSettings,omitempty"`
+	Throttles            []ProviderThrottleInput    `json:"throttles,omitempty"`
+	Regions              []ProviderRegionInput      `json:"regions,omitempty"`
+	KeyStrategy          *KeyStrategy               `json:"keyStrategy,omitempty"`
+	HuggingFaceEndpoints []HuggingFaceEndpointInput `json:"huggingFaceEndpoints,omitempty"`
+}
+
+type UpdateRetentionPolicyInput struct {
+	Table               string `json:"table"`
+	RetentionDays       *int   `json:"retentionDays,omitempty"`
+	ArchiveBeforeDelete *bool  `json:"archiveBeforeDelete,omitempty"`
+	Enabled             *bool  `json:"enabled,omitempty"`
 }
 
 type UpdateRoleInput struct {
-	Name        *string `json:"name,omitempty"`
-	Description *string `json:"description,omitempty"`
-	IsDefault   *bool   `json:"isDefault,omitempty"`
+	Name        *string  `json:"name,omitempty"`
+	Description *string  `json:"description,omitempty"`
+	IsDefault   *bool    `json:"isDefault,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
 }
 
 type UpdateTenantInput struct {
@@ -1563,16 +2749,53 @@ type UpdateTenantInput struct {
 	PlanLimitsOverride *PlanLimitsInput `json:"planLimitsOverride,omitempty"`
 }
 
+type UsageExportDataset struct {
+	Dataset       string     `json:"dataset"`
+	ExportedUntil *time.Time `json:"exportedUntil,omitempty"`
+	LastRunAt     *time.Time `json:"lastRunAt,omitempty"`
+	LastObjectKey *string    `json:"lastObjectKey,omitempty"`
+	LastRowCount  int        `json:"lastRowCount"`
+	LastError     *string    `json:"lastError,omitempty"`
+}
+
+type UsageExportFile struct {
+	Dataset        string    `json:"dataset"`
+	PartitionStart time.Time `json:"partitionStart"`
+	PartitionEnd   time.Time `json:"partitionEnd"`
+	ObjectKey      *string   `json:"objectKey,omitempty"`
+	RowCount       int       `json:"rowCount"`
+}
+
+type UsageExportStatus struct {
+	Enabled     bool                 `json:"enabled"`
+	Format      string               `json:"format"`
+	Partition   string               `json:"partition"`
+	Destination *string              `json:"destination,omitempty"`
+	Datasets    []UsageExportDataset `json:"datasets"`
+}
+
 type User struct {
-	ID             string     `json:"id"`
-	Email          string     `json:"email"`
-	Name           string     `json:"name"`
-	Role           string     `json:"role"`
-	Status         string     `json:"status"`
-	CreatedAt      time.Time  `json:"createdAt"`
-	CreatedBy      *string    `json:"createdBy,omitempty"`
-	CreatedByEmail *string    `json:"createdByEmail,omitempty"`
-	LastLoginAt    *time.Time `json:"lastLoginAt,omitempty"`
+	ID               string     `json:"id"`
+	Email            string     `json:"email"`
+	Name             string     `json:"name"`
+	Role             string     `json:"role"`
+	Status           string     `json:"status"`
+	CreatedAt        time.Time  `json:"createdAt"`
+	CreatedBy        *string    `json:"createdBy,omitempty"`
+	CreatedByEmail   *string    `json:"createdByEmail,omitempty"`
+	LastLoginAt      *time.Time `json:"lastLoginAt,omitempty"`
+	APIRoleID        *string    `json:"apiRoleId,omitempty"`
+	DashboardRoleID  *string    `json:"dashboardRoleId,omitempty"`
+	TwoFactorEnabled bool       `json:"twoFactorEnabled"`
+}
+
+type UserUsage struct {
+	UserID     string  `json:"userId"`
+	Email      string  `json:"email"`
+	Requests   int     `json:"requests"`
+	Tokens     int     `json:"tokens"`
+	Cost       float64 `json:"cost"`
+	Percentage float64 `json:"percentage"`
 }
 
 type WeightedRoutingConfig struct {
@@ -1697,56 +2920,48 @@ func (e AlertType) MarshalJSON() ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
-type AuditAction string
+type AnalyticsInterval string
 
 const (
-	AuditActionCreate AuditAction = "CREATE"
-	AuditActionUpdate AuditAction = "UPDATE"
-	AuditActionDelete AuditAction = "DELETE"
-	AuditActionRevoke AuditAction = "REVOKE"
-	AuditActionLogin  AuditAction = "LOGIN"
-	AuditActionLogout AuditAction = "LOGOUT"
+	AnalyticsIntervalHour AnalyticsInterval = "HOUR"
+	AnalyticsIntervalDay  AnalyticsInterval = "DAY"
 )
 
-var AllAuditAction = []AuditAction{
-	AuditActionCreate,
-	AuditActionUpdate,
-	AuditActionDelete,
-	AuditActionRevoke,
-	AuditActionLogin,
-	AuditActionLogout,
+var AllAnalyticsInterval = []AnalyticsInterval{
+	AnalyticsIntervalHour,
+	AnalyticsIntervalDay,
 }
 
-func (e AuditAction) IsValid() bool {
+func (e AnalyticsInterval) IsValid() bool {
 	switch e {
-	case AuditActionCreate, AuditActionUpdate, AuditActionDelete, AuditActionRevoke, AuditActionLogin, AuditActionLogout:
+	case AnalyticsIntervalHour, AnalyticsIntervalDay:
 		return true
 	}
 	return false
 }
 
-func (e AuditAction) String() string {
+func (e AnalyticsInterval) String() string {
 	return string(e)
 }
 
-func (e *AuditAction) UnmarshalGQL(v any) error {
+func (e *AnalyticsInterval) UnmarshalGQL(v any) error {
 	str, ok := v.(string)
 	if !ok {
 		return fmt.Errorf("enums must be strings")
 	}
 
-	*e = AuditAction(str)
+	*e = AnalyticsInterval(str)
 	if !e.IsValid() {
-		return fmt.Errorf("%s is not a valid AuditAction", str)
+		return fmt.Errorf("%s is not a valid AnalyticsInterval", str)
 	}
 	return nil
 }
 
-func (e AuditAction) MarshalGQL(w io.Writer) {
+func (e AnalyticsInterval) MarshalGQL(w io.Writer) {
 	fmt.Fprint(w, strconv.Quote(e.String()))
 }
 
-func (e *AuditAction) UnmarshalJSON(b []byte) error {
+func (e *AnalyticsInterval) UnmarshalJSON(b []byte) error {
 	s, err := strconv.Unquote(string(b))
 	if err != nil {
 		return err
@@ -1754,23 +2969,222 @@ func (e *AuditAction) UnmarshalJSON(b []byte) error {
 	return e.UnmarshalGQL(s)
 }
 
-func (e AuditAction) MarshalJSON() ([]byte, error) {
+func (e AnalyticsInterval) MarshalJSON() ([]byte, error) {
 	var buf bytes.Buffer
 	e.MarshalGQL(&buf)
 	return buf.Bytes(), nil
 }
 
-type AuditResourceType string
+type AuditAction string
+
+const (
+	AuditActionCreate       AuditAction = "CREATE"
+	AuditActionUpdate       AuditAction = "UPDATE"
+	AuditActionDelete       AuditAction = "DELETE"
+	AuditActionRevoke       AuditAction = "REVOKE"
+	AuditActionLogin        AuditAction = "LOGIN"
+	AuditActionLogout       AuditAction = "LOGOUT"
+	AuditActionAccessDenied AuditAction = "ACCESS_DENIED"
+	AuditActionExport       AuditAction = "EXPORT"
+	AuditActionApprove      AuditAction = "APPROVE"
+	AuditActionReject       AuditAction = "REJECT"
+	AuditActionDisable      AuditAction = "DISABLE"
+	AuditActionEnable       AuditAction = "ENABLE"
+)
+
+var AllAuditAction = []AuditAction{
+	AuditActionCreate,
+	AuditActionUpdate,
+	AuditActionDelete,
+	AuditActionRevoke,
+	AuditActionLogin,
+	AuditActionLogout,
+	AuditActionAccessDenied,
+	AuditActionExport,
+	AuditActionApprove,
+	AuditActionReject,
+	AuditActionDisable,
+	AuditActionEnable,
+}
+
+func (e AuditAction) IsValid() bool {
+	switch e {
+	case AuditActionCreate, AuditActionUpdate, AuditActionDelete, AuditActionRevoke, AuditActionLogin, AuditActionLogout, AuditActionAccessDenied, AuditActionExport, AuditActionApprove, AuditActionReject, AuditActionDisable, AuditActionEnable:
+		return true
+	}
+	return false
+}
+
+func (e AuditAction) String() string {
+	return string(e)
+}
+
+func (e *AuditAction) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = AuditAction(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid AuditAction", str)
+	}
+	return nil
+}
+
+func (e AuditAction) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *AuditAction) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e AuditAction) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type AuditExportFormat string
 
 const (
-	AuditResourceTypeRole     AuditResourceType = "ROLE"
-	AuditResourceTypePolicy   AuditResourceType = "POLICY"
-	AuditResourceTypeGroup    AuditResourceType = "GROUP"
-	AuditResourceTypeAPIKey   AuditResourceType = "API_KEY"
-	AuditResourceTypeUser     AuditResourceType = "USER"
-	AuditResourceTypeProvider AuditResourceType = "PROVIDER"
-	AuditResourceTypeTenant   AuditResourceType = "TENANT"
-	AuditResourceTypeSession  AuditResourceType = "SESSION"
+	AuditExportFormatCSV   AuditExportFormat = "CSV"
+	AuditExportFormatJSONL AuditExportFormat = "JSONL"
+)
+
+var AllAuditExportFormat = []AuditExportFormat{
+	AuditExportFormatCSV,
+	AuditExportFormatJSONL,
+}
+
+func (e AuditExportFormat) IsValid() bool {
+	switch e {
+	case AuditExportFormatCSV, AuditExportFormatJSONL:
+		return true
+	}
+	return false
+}
+
+func (e AuditExportFormat) String() string {
+	return string(e)
+}
+
+func (e *AuditExportFormat) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = AuditExportFormat(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid AuditExportFormat", str)
+	}
+	return nil
+}
+
+func (e AuditExportFormat) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *AuditExportFormat) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e AuditExportFormat) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type AuditExportStatus string
+
+const (
+	AuditExportStatusPending   AuditExportStatus = "PENDING"
+	AuditExportStatusRunning   AuditExportStatus = "RUNNING"
+	AuditExportStatusCompleted AuditExportStatus = "COMPLETED"
+	AuditExportStatusFailed    AuditExportStatus = "FAILED"
+)
+
+var AllAuditExportStatus = []AuditExportStatus{
+	AuditExportStatusPending,
+	AuditExportStatusRunning,
+	AuditExportStatusCompleted,
+	AuditExportStatusFailed,
+}
+
+func (e AuditExportStatus) IsValid() bool {
+	switch e {
+	case AuditExportStatusPending, AuditExportStatusRunning, AuditExportStatusCompleted, AuditExportStatusFailed:
+		return true
+	}
+	return false
+}
+
+func (e AuditExportStatus) String() string {
+	return string(e)
+}
+
+func (e *AuditExportStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = AuditExportStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid AuditExportStatus", str)
+	}
+	return nil
+}
+
+func (e AuditExportStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *AuditExportStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e AuditExportStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type AuditResourceType string
+
+const (
+	AuditResourceTypeRole            AuditResourceType = "ROLE"
+	AuditResourceTypePolicy          AuditResourceType = "POLICY"
+	AuditResourceTypeGroup           AuditResourceType = "GROUP"
+	AuditResourceTypeAPIKey          AuditResourceType = "API_KEY"
+	AuditResourceTypeUser            AuditResourceType = "USER"
+	AuditResourceTypeProvider        AuditResourceType = "PROVIDER"
+	AuditResourceTypeTenant          AuditResourceType = "TENANT"
+	AuditResourceTypeSession         AuditResourceType = "SESSION"
+	AuditResourceTypeRetentionPolicy AuditResourceType = "RETENTION_POLICY"
+	AuditResourceTypeFeatureFlag     AuditResourceType = "FEATURE_FLAG"
+	AuditResourceTypeConfig          AuditResourceType = "CONFIG"
+	AuditResourceTypeModelPrice      AuditResourceType = "MODEL_PRICE"
+	AuditResourceTypeAuditLog        AuditResourceType = "AUDIT_LOG"
+	AuditResourceTypeProject         AuditResourceType = "PROJECT"
+	AuditResourceTypeCustomModel     AuditResourceType = "CUSTOM_MODEL"
+	AuditResourceTypeCacheEntry      AuditResourceType = "CACHE_ENTRY"
+	AuditResourceTypeRegistration    AuditResourceType = "REGISTRATION"
+	AuditResourceTypeKillSwitch      AuditResourceType = "KILL_SWITCH"
 )
 
 var AllAuditResourceType = []AuditResourceType{
@@ -1782,11 +3196,21 @@ var AllAuditResourceType = []AuditResourceType{
 	AuditResourceTypeProvider,
 	AuditResourceTypeTenant,
 	AuditResourceTypeSession,
+	AuditResourceTypeRetentionPolicy,
+	AuditResourceTypeFeatureFlag,
+	AuditResourceTypeConfig,
+	AuditResourceTypeModelPrice,
+	AuditResourceTypeAuditLog,
+	AuditResourceTypeProject,
+	AuditResourceTypeCustomModel,
+	AuditResourceTypeCacheEntry,
+	AuditResourceTypeRegistration,
+	AuditResourceTypeKillSwitch,
 }
 
 func (e AuditResourceType) IsValid() bool {
 	switch e {
-	case AuditResourceTypeRole, AuditResourceTypePolicy, AuditResourceTypeGroup, AuditResourceTypeAPIKey, AuditResourceTypeUser, AuditResourceTypeProvider, AuditResourceTypeTenant, AuditResourceTypeSession:
+	case AuditResourceTypeRole, AuditResourceTypePolicy, AuditResourceTypeGroup, AuditResourceTypeAPIKey, AuditResourceTypeUser, AuditResourceTypeProvider, AuditResourceTypeTenant, AuditResourceTypeSession, AuditResourceTypeRetentionPolicy, AuditResourceTypeFeatureFlag, AuditResourceTypeConfig, AuditResourceTypeModelPrice, AuditResourceTypeAuditLog, AuditResourceTypeProject, AuditResourceTypeCustomModel, AuditResourceTypeCacheEntry, AuditResourceTypeRegistration, AuditResourceTypeKillSwitch:
 		return true
 	}
 	return false
@@ -1884,54 +3308,457 @@ func (e BudgetExceededAction) MarshalJSON() ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+type CertSource string
+
+const (
+	CertSourceAcme     CertSource = "ACME"
+	CertSourceUploaded CertSource = "UPLOADED"
+)
+
+var AllCertSource = []CertSource{
+	CertSourceAcme,
+	CertSourceUploaded,
+}
+
+func (e CertSource) IsValid() bool {
+	switch e {
+	case CertSourceAcme, CertSourceUploaded:
+		return true
+	}
+	return false
+}
+
+func (e CertSource) String() string {
+	return string(e)
+}
+
+func (e *CertSource) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = CertSource(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid CertSource", str)
+	}
+	return nil
+}
+
+func (e CertSource) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *CertSource) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e CertSource) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type ContextStrategy string
+
+const (
+	ContextStrategyTruncate    ContextStrategy = "TRUNCATE"
+	ContextStrategySummarize   ContextStrategy = "SUMMARIZE"
+	ContextStrategyLargerModel ContextStrategy = "LARGER_MODEL"
+)
+
+var AllContextStrategy = []ContextStrategy{
+	ContextStrategyTruncate,
+	ContextStrategySummarize,
+	ContextStrategyLargerModel,
+}
+
+func (e ContextStrategy) IsValid() bool {
+	switch e {
+	case ContextStrategyTruncate, ContextStrategySummarize, ContextStrategyLargerModel:
+		return true
+	}
+	return false
+}
+
+func (e ContextStrategy) String() string {
+	return string(e)
+}
+
+func (e *ContextStrategy) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ContextStrategy(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ContextStrategy", str)
+	}
+	return nil
+}
+
+func (e ContextStrategy) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *ContextStrategy) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e ContextStrategy) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
 type DetectionAction string
 
 const (
-	DetectionActionBlock      DetectionAction = "BLOCK"
-	DetectionActionWarn       DetectionAction = "WARN"
-	DetectionActionLog        DetectionAction = "LOG"
-	DetectionActionQuarantine DetectionAction = "QUARANTINE"
-	DetectionActionTransform  DetectionAction = "TRANSFORM"
+	DetectionActionBlock      DetectionAction = "BLOCK"
+	DetectionActionWarn       DetectionAction = "WARN"
+	DetectionActionLog        DetectionAction = "LOG"
+	DetectionActionQuarantine DetectionAction = "QUARANTINE"
+	DetectionActionTransform  DetectionAction = "TRANSFORM"
+)
+
+var AllDetectionAction = []DetectionAction{
+	DetectionActionBlock,
+	DetectionActionWarn,
+	DetectionActionLog,
+	DetectionActionQuarantine,
+	DetectionActionTransform,
+}
+
+func (e DetectionAction) IsValid() bool {
+	switch e {
+	case DetectionActionBlock, DetectionActionWarn, DetectionActionLog, DetectionActionQuarantine, DetectionActionTransform:
+		return true
+	}
+	return false
+}
+
+func (e DetectionAction) String() string {
+	return string(e)
+}
+
+func (e *DetectionAction) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = DetectionAction(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid DetectionAction", str)
+	}
+	return nil
+}
+
+func (e DetectionAction) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *DetectionAction) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e DetectionAction) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type DetectionMethod string
+
+const (
+	DetectionMethodRules  DetectionMethod = "RULES"
+	DetectionMethodMl     DetectionMethod = "ML"
+	DetectionMethodHybrid DetectionMethod = "HYBRID"
+)
+
+var AllDetectionMethod = []DetectionMethod{
+	DetectionMethodRules,
+	DetectionMethodMl,
+	DetectionMethodHybrid,
+}
+
+func (e DetectionMethod) IsValid() bool {
+	switch e {
+	case DetectionMethodRules, DetectionMethodMl, DetectionMethodHybrid:
+		return true
+	}
+	return false
+}
+
+func (e DetectionMethod) String() string {
+	return string(e)
+}
+
+func (e *DetectionMethod) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = DetectionMethod(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid DetectionMethod", str)
+	}
+	return nil
+}
+
+func (e DetectionMethod) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *DetectionMethod) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e DetectionMethod) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type DetectionSensitivity string
+
+const (
+	DetectionSensitivityLow      DetectionSensitivity = "LOW"
+	DetectionSensitivityMedium   DetectionSensitivity = "MEDIUM"
+	DetectionSensitivityHigh     DetectionSensitivity = "HIGH"
+	DetectionSensitivityParanoid DetectionSensitivity = "PARANOID"
+)
+
+var AllDetectionSensitivity = []DetectionSensitivity{
+	DetectionSensitivityLow,
+	DetectionSensitivityMedium,
+	DetectionSensitivityHigh,
+	DetectionSensitivityParanoid,
+}
+
+func (e DetectionSensitivity) IsValid() bool {
+	switch e {
+	case DetectionSensitivityLow, DetectionSensitivityMedium, DetectionSensitivityHigh, DetectionSensitivityParanoid:
+		return true
+	}
+	return false
+}
+
+func (e DetectionSensitivity) String() string {
+	return string(e)
+}
+
+func (e *DetectionSensitivity) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = DetectionSensitivity(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid DetectionSensitivity", str)
+	}
+	return nil
+}
+
+func (e DetectionSensitivity) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *DetectionSensitivity) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e DetectionSensitivity) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type DigestFrequency string
+
+const (
+	DigestFrequencyOff    DigestFrequency = "OFF"
+	DigestFrequencyDaily  DigestFrequency = "DAILY"
+	DigestFrequencyWeekly DigestFrequency = "WEEKLY"
+)
+
+var AllDigestFrequency = []DigestFrequency{
+	DigestFrequencyOff,
+	DigestFrequencyDaily,
+	DigestFrequencyWeekly,
+}
+
+func (e DigestFrequency) IsValid() bool {
+	switch e {
+	case DigestFrequencyOff, DigestFrequencyDaily, DigestFrequencyWeekly:
+		return true
+	}
+	return false
+}
+
+func (e DigestFrequency) String() string {
+	return string(e)
+}
+
+func (e *DigestFrequency) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = DigestFrequency(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid DigestFrequency", str)
+	}
+	return nil
+}
+
+func (e DigestFrequency) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *DigestFrequency) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e DigestFrequency) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type EmbedderFeature string
+
+const (
+	EmbedderFeatureSemanticCache      EmbedderFeature = "SEMANTIC_CACHE"
+	EmbedderFeatureToolSearch         EmbedderFeature = "TOOL_SEARCH"
+	EmbedderFeatureInjectionDetection EmbedderFeature = "INJECTION_DETECTION"
+	EmbedderFeatureDocuments          EmbedderFeature = "DOCUMENTS"
+)
+
+var AllEmbedderFeature = []EmbedderFeature{
+	EmbedderFeatureSemanticCache,
+	EmbedderFeatureToolSearch,
+	EmbedderFeatureInjectionDetection,
+	EmbedderFeatureDocuments,
+}
+
+func (e EmbedderFeature) IsValid() bool {
+	switch e {
+	case EmbedderFeatureSemanticCache, EmbedderFeatureToolSearch, EmbedderFeatureInjectionDetection, EmbedderFeatureDocuments:
+		return true
+	}
+	return false
+}
+
+func (e EmbedderFeature) String() string {
+	return string(e)
+}
+
+func (e *EmbedderFeature) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = EmbedderFeature(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid EmbedderFeature", str)
+	}
+	return nil
+}
+
+func (e EmbedderFeature) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *EmbedderFeature) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e EmbedderFeature) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type ExperimentStatus string
+
+const (
+	ExperimentStatusDraft     ExperimentStatus = "DRAFT"
+	ExperimentStatusRunning   ExperimentStatus = "RUNNING"
+	ExperimentStatusPaused    ExperimentStatus = "PAUSED"
+	ExperimentStatusCompleted ExperimentStatus = "COMPLETED"
 )
 
-var AllDetectionAction = []DetectionAction{
-	DetectionActionBlock,
-	DetectionActionWarn,
-	DetectionActionLog,
-	DetectionActionQuarantine,
-	DetectionActionTransform,
+var AllExperimentStatus = []ExperimentStatus{
+	ExperimentStatusDraft,
+	ExperimentStatusRunning,
+	ExperimentStatusPaused,
+	ExperimentStatusCompleted,
 }
 
-func (e DetectionAction) IsValid() bool {
+func (e ExperimentStatus) IsValid() bool {
 	switch e {
-	case DetectionActionBlock, DetectionActionWarn, DetectionActionLog, DetectionActionQuarantine, DetectionActionTransform:
+	case ExperimentStatusDraft, ExperimentStatusRunning, ExperimentStatusPaused, ExperimentStatusCompleted:
 		return true
 	}
 	return false
 }
 
-func (e DetectionAction) String() string {
+func (e ExperimentStatus) String() string {
 	return string(e)
 }
 
-func (e *DetectionAction) UnmarshalGQL(v any) error {
+func (e *ExperimentStatus) UnmarshalGQL(v any) error {
 	str, ok := v.(string)
 	if !ok {
 		return fmt.Errorf("enums must be strings")
 	}
 
-	*e = DetectionAction(str)
+	*e = ExperimentStatus(str)
 	if !e.IsValid() {
-		return fmt.Errorf("%s is not a valid DetectionAction", str)
+		return fmt.Errorf("%s is not a valid ExperimentStatus", str)
 	}
 	return nil
 }
 
-func (e DetectionAction) MarshalGQL(w io.Writer) {
+func (e ExperimentStatus) MarshalGQL(w io.Writer) {
 	fmt.Fprint(w, strconv.Quote(e.String()))
 }
 
-func (e *DetectionAction) UnmarshalJSON(b []byte) error {
+func (e *ExperimentStatus) UnmarshalJSON(b []byte) error {
 	s, err := strconv.Unquote(string(b))
 	if err != nil {
 		return err
@@ -1939,56 +3766,54 @@ func (e *DetectionAction) UnmarshalJSON(b []byte) error {
 	return e.UnmarshalGQL(s)
 }
 
-func (e DetectionAction) MarshalJSON() ([]byte, error) {
+func (e ExperimentStatus) MarshalJSON() ([]byte, error) {
 	var buf bytes.Buffer
 	e.MarshalGQL(&buf)
 	return buf.Bytes(), nil
 }
 
-type DetectionMethod string
+type InjectionCategory string
 
 const (
-	DetectionMethodRules  DetectionMethod = "RULES"
-	DetectionMethodMl     DetectionMethod = "ML"
-	DetectionMethodHybrid DetectionMethod = "HYBRID"
+	InjectionCategoryInjection InjectionCategory = "INJECTION"
+	InjectionCategoryJailbreak InjectionCategory = "JAILBREAK"
 )
 
-var AllDetectionMethod = []DetectionMethod{
-	DetectionMethodRules,
-	DetectionMethodMl,
-	DetectionMethodHybrid,
+var AllInjectionCategory = []InjectionCategory{
+	InjectionCategoryInjection,
+	InjectionCategoryJailbreak,
 }
 
-func (e DetectionMethod) IsValid() bool {
+func (e InjectionCategory) IsValid() bool {
 	switch e {
-	case DetectionMethodRules, DetectionMethodMl, DetectionMethodHybrid:
+	case InjectionCategoryInjection, InjectionCategoryJailbreak:
 		return true
 	}
 	return false
 }
 
-func (e DetectionMethod) String() string {
+func (e InjectionCategory) String() string {
 	return string(e)
 }
 
-func (e *DetectionMethod) UnmarshalGQL(v any) error {
+func (e *InjectionCategory) UnmarshalGQL(v any) error {
 	str, ok := v.(string)
 	if !ok {
 		return fmt.Errorf("enums must be strings")
 	}
 
-	*e = DetectionMethod(str)
+	*e = InjectionCategory(str)
 	if !e.IsValid() {
-		return fmt.Errorf("%s is not a valid DetectionMethod", str)
+		return fmt.Errorf("%s is not a valid InjectionCategory", str)
 	}
 	return nil
 }
 
-func (e DetectionMethod) MarshalGQL(w io.Writer) {
+func (e InjectionCategory) MarshalGQL(w io.Writer) {
 	fmt.Fprint(w, strconv.Quote(e.String()))
 }
 
-func (e *DetectionMethod) UnmarshalJSON(b []byte) error {
+func (e *InjectionCategory) UnmarshalJSON(b []byte) error {
 	s, err := strconv.Unquote(string(b))
 	if err != nil {
 		return err
@@ -1996,58 +3821,56 @@ func (e *DetectionMethod) UnmarshalJSON(b []byte) error {
 	return e.UnmarshalGQL(s)
 }
 
-func (e DetectionMethod) MarshalJSON() ([]byte, error) {
+func (e InjectionCategory) MarshalJSON() ([]byte, error) {
 	var buf bytes.Buffer
 	e.MarshalGQL(&buf)
 	return buf.Bytes(), nil
 }
 
-type DetectionSensitivity string
+type KeyStrategy string
 
 const (
-	DetectionSensitivityLow      DetectionSensitivity = "LOW"
-	DetectionSensitivityMedium   DetectionSensitivity = "MEDIUM"
-	DetectionSensitivityHigh     DetectionSensitivity = "HIGH"
-	DetectionSensitivityParanoid DetectionSensitivity = "PARANOID"
+	KeyStrategyRoundRobin  KeyStrategy = "ROUND_ROBIN"
+	KeyStrategyLeastErrors KeyStrategy = "LEAST_ERRORS"
+	KeyStrategyWeighted    KeyStrategy = "WEIGHTED"
 )
 
-var AllDetectionSensitivity = []DetectionSensitivity{
-	DetectionSensitivityLow,
-	DetectionSensitivityMedium,
-	DetectionSensitivityHigh,
-	DetectionSensitivityParanoid,
+var AllKeyStrategy = []KeyStrategy{
+	KeyStrategyRoundRobin,
+	KeyStrategyLeastErrors,
+	KeyStrategyWeighted,
 }
 
-func (e DetectionSensitivity) IsValid() bool {
+func (e KeyStrategy) IsValid() bool {
 	switch e {
-	case DetectionSensitivityLow, DetectionSensitivityMedium, DetectionSensitivityHigh, DetectionSensitivityParanoid:
+	case KeyStrategyRoundRobin, KeyStrategyLeastErrors, KeyStrategyWeighted:
 		return true
 	}
 	return false
 }
 
-func (e DetectionSensitivity) String() string {
+func (e KeyStrategy) String() string {
 	return string(e)
 }
 
-func (e *DetectionSensitivity) UnmarshalGQL(v any) error {
+func (e *KeyStrategy) UnmarshalGQL(v any) error {
 	str, ok := v.(string)
 	if !ok {
 		return fmt.Errorf("enums must be strings")
 	}
 
-	*e = DetectionSensitivity(str)
+	*e = KeyStrategy(str)
 	if !e.IsValid() {
-		return fmt.Errorf("%s is not a valid DetectionSensitivity", str)
+		return fmt.Errorf("%s is not a valid KeyStrategy", str)
 	}
 	return nil
 }
 
-func (e DetectionSensitivity) MarshalGQL(w io.Writer) {
+func (e KeyStrategy) MarshalGQL(w io.Writer) {
 	fmt.Fprint(w, strconv.Quote(e.String()))
 }
 
-func (e *DetectionSensitivity) UnmarshalJSON(b []byte) error {
+func (e *KeyStrategy) UnmarshalJSON(b []byte) error {
 	s, err := strconv.Unquote(string(b))
 	if err != nil {
 		return err
@@ -2055,7 +3878,7 @@ func (e *DetectionSensitivity) UnmarshalJSON(b []byte) error {
 	return e.UnmarshalGQL(s)
 }
 
-func (e DetectionSensitivity) MarshalJSON() ([]byte, error) {
+func (e KeyStrategy) MarshalJSON() ([]byte, error) {
 	var buf bytes.Buffer
 	e.MarshalGQL(&buf)
 	return buf.Bytes(), nil
@@ -2358,6 +4181,63 @@ func (e MCPToolVisibility) MarshalJSON() ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+type OllamaPullStatus string
+
+const (
+	OllamaPullStatusPulling   OllamaPullStatus = "PULLING"
+	OllamaPullStatusCompleted OllamaPullStatus = "COMPLETED"
+	OllamaPullStatusFailed    OllamaPullStatus = "FAILED"
+)
+
+var AllOllamaPullStatus = []OllamaPullStatus{
+	OllamaPullStatusPulling,
+	OllamaPullStatusCompleted,
+	OllamaPullStatusFailed,
+}
+
+func (e OllamaPullStatus) IsValid() bool {
+	switch e {
+	case OllamaPullStatusPulling, OllamaPullStatusCompleted, OllamaPullStatusFailed:
+		return true
+	}
+	return false
+}
+
+func (e OllamaPullStatus) String() string {
+	return string(e)
+}
+
+func (e *OllamaPullStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = OllamaPullStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid OllamaPullStatus", str)
+	}
+	return nil
+}
+
+func (e OllamaPullStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *OllamaPullStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e OllamaPullStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
 type OutputViolationAction string
 
 const (
@@ -2480,6 +4360,65 @@ func (e PIIAction) MarshalJSON() ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+type PriceSource string
+
+const (
+	PriceSourceCatalog  PriceSource = "CATALOG"
+	PriceSourceProvider PriceSource = "PROVIDER"
+	PriceSourceConfig   PriceSource = "CONFIG"
+	PriceSourceOverride PriceSource = "OVERRIDE"
+)
+
+var AllPriceSource = []PriceSource{
+	PriceSourceCatalog,
+	PriceSourceProvider,
+	PriceSourceConfig,
+	PriceSourceOverride,
+}
+
+func (e PriceSource) IsValid() bool {
+	switch e {
+	case PriceSourceCatalog, PriceSourceProvider, PriceSourceConfig, PriceSourceOverride:
+		return true
+	}
+	return false
+}
+
+func (e PriceSource) String() string {
+	return string(e)
+}
+
+func (e *PriceSource) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = PriceSource(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid PriceSource", str)
+	}
+	return nil
+}
+
+func (e PriceSource) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *PriceSource) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e PriceSource) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
 type Provider string
 
 const (
@@ -2493,6 +4432,12 @@ const (
 	ProviderMistral     Provider = "MISTRAL"
 	ProviderTogether    Provider = "TOGETHER"
 	ProviderCohere      Provider = "COHERE"
+	ProviderOpenrouter  Provider = "OPENROUTER"
+	ProviderXai         Provider = "XAI"
+	ProviderDeepseek    Provider = "DEEPSEEK"
+	ProviderHuggingface Provider = "HUGGINGFACE"
+	ProviderFireworks   Provider = "FIREWORKS"
+	ProviderCustom      Provider = "CUSTOM"
 )
 
 var AllProvider = []Provider{
@@ -2506,11 +4451,17 @@ var AllProvider = []Provider{
 	ProviderMistral,
 	ProviderTogether,
 	ProviderCohere,
+	ProviderOpenrouter,
+	ProviderXai,
+	ProviderDeepseek,
+	ProviderHuggingface,
+	ProviderFireworks,
+	ProviderCustom,
 }
 
 func (e Provider) IsValid() bool {
 	switch e {
-	case ProviderOpenai, ProviderAnthropic, ProviderGemini, ProviderBedrock, ProviderAzureOpenai, ProviderOllama, ProviderGroq, ProviderMistral, ProviderTogether, ProviderCohere:
+	case ProviderOpenai, ProviderAnthropic, ProviderGemini, ProviderBedrock, ProviderAzureOpenai, ProviderOllama, ProviderGroq, ProviderMistral, ProviderTogether, ProviderCohere, ProviderOpenrouter, ProviderXai, ProviderDeepseek, ProviderHuggingface, ProviderFireworks, ProviderCustom:
 		return true
 	}
 	return false
@@ -2551,6 +4502,65 @@ func (e Provider) MarshalJSON() ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+type ReplayDiffKind string
+
+const (
+	ReplayDiffKindEqual   ReplayDiffKind = "EQUAL"
+	ReplayDiffKindChanged ReplayDiffKind = "CHANGED"
+	ReplayDiffKindRemoved ReplayDiffKind = "REMOVED"
+	ReplayDiffKindAdded   ReplayDiffKind = "ADDED"
+)
+
+var AllReplayDiffKind = []ReplayDiffKind{
+	ReplayDiffKindEqual,
+	ReplayDiffKindChanged,
+	ReplayDiffKindRemoved,
+	ReplayDiffKindAdded,
+}
+
+func (e ReplayDiffKind) IsValid() bool {
+	switch e {
+	case ReplayDiffKindEqual, ReplayDiffKindChanged, ReplayDiffKindRemoved, ReplayDiffKindAdded:
+		return true
+	}
+	return false
+}
+
+func (e ReplayDiffKind) String() string {
+	return string(e)
+}
+
+func (e *ReplayDiffKind) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ReplayDiffKind(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ReplayDiffKind", str)
+	}
+	return nil
+}
+
+func (e ReplayDiffKind) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *ReplayDiffKind) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e ReplayDiffKind) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
 type RoutingStrategy string
 
 const (
@@ -2671,6 +4681,63 @@ func (e SearchStrategy) MarshalJSON() ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+type SystemPromptMode string
+
+const (
+	SystemPromptModeReplace SystemPromptMode = "REPLACE"
+	SystemPromptModePrepend SystemPromptMode = "PREPEND"
+	SystemPromptModeAppend  SystemPromptMode = "APPEND"
+)
+
+var AllSystemPromptMode = []SystemPromptMode{
+	SystemPromptModeReplace,
+	SystemPromptModePrepend,
+	SystemPromptModeAppend,
+}
+
+func (e SystemPromptMode) IsValid() bool {
+	switch e {
+	case SystemPromptModeReplace, SystemPromptModePrepend, SystemPromptModeAppend:
+		return true
+	}
+	return false
+}
+
+func (e SystemPromptMode) String() string {
+	return string(e)
+}
+
+func (e *SystemPromptMode) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = SystemPromptMode(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid SystemPromptMode", str)
+	}
+	return nil
+}
+
+func (e SystemPromptMode) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *SystemPromptMode) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e SystemPromptMode) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
 type TemplateFormat string
 
 const (
@@ -2844,6 +4911,61 @@ func (e TenantTier) MarshalJSON() ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+type Thumbs string
+
+const (
+	ThumbsUp   Thumbs = "UP"
+	ThumbsDown Thumbs = "DOWN"
+)
+
+var AllThumbs = []Thumbs{
+	ThumbsUp,
+	ThumbsDown,
+}
+
+func (e Thumbs) IsValid() bool {
+	switch e {
+	case ThumbsUp, ThumbsDown:
+		return true
+	}
+	return false
+}
+
+func (e Thumbs) String() string {
+	return string(e)
+}
+
+func (e *Thumbs) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = Thumbs(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid Thumbs", str)
+	}
+	return nil
+}
+
+func (e Thumbs) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *Thumbs) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e Thumbs) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
 type ToolPermissionStatus string
 
 const (
@@ -0,0 +1,113 @@
+package resolver
+
+import (
+	"context"
+	"strings"
+
+	"modelgate/internal/domain"
+	"modelgate/internal/graphql/generated"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// ownRecordsKey marks a field resolved for a user who may only see their own records
+type ownRecordsKey struct{}
+
+// accessDirectives are the schema directives that declare who may resolve a field
+var accessDirectives = []string{"hasPermission", "authenticated", "public"}
+
+// Directives implements the access control directives declared in the schema
+func Directives() generated.DirectiveRoot {
+	return generated.DirectiveRoot{
+		HasPermission: hasPermissionDirective,
+		Authenticated: authenticatedDirective,
+		Public: func(ctx context.Context, _ any, next graphql.Resolver) (any, error) {
+			return next(ctx)
+		},
+	}
+}
+
+// hasPermissionDirective resolves the field if the user has the permission.
+// Otherwise, with own, a user who has that permission gets the field limited
+// to their own records. A denied field resolves to null with an error, so the
+// rest of the query still returns data when the field is nullable.
+func hasPermissionDirective(ctx context.Context, _ any, next graphql.Resolver, permission string, own *string) (any, error) {
+	if GetUserFromContext(ctx) == "" {
+		return nil, errUnauthenticated()
+	}
+	if HasPermissionFromContext(ctx, permission) {
+		return next(ctx)
+	}
+	if own != nil && HasPermissionFromContext(ctx, *own) {
+		return next(context.WithValue(ctx, ownRecordsKey{}, true))
+	}
+	return nil, errForbidden(permission)
+}
+
+// authenticatedDirective resolves the field for any signed-in user
+func authenticatedDirective(ctx context.Context, _ any, next graphql.Resolver) (any, error) {
+	if GetUserFromContext(ctx) == "" {
+		return nil, errUnauthenticated()
+	}
+	return next(ctx)
+}
+
+// RequireDeclaredAccess is a root field middleware that only lets users with
+// every permission ("*") resolve Query, Mutation and Subscription fields that
+// declare none of the access directives, so a field added without one is not
+// open to everyone.
+func RequireDeclaredAccess(ctx context.Context, next graphql.RootResolver) graphql.Marshaler {
+	fc := graphql.GetRootFieldContext(ctx)
+	if fc == nil || strings.HasPrefix(fc.Field.Name, "__") || declaresAccess(fc.Field.Definition) ||
+		HasPermissionFromContext(ctx, "*") {
+		return next(ctx)
+	}
+	graphql.AddError(ctx, errForbidden("*"))
+	return graphql.Null
+}
+
+func declaresAccess(def *ast.FieldDefinition) bool {
+	if def == nil {
+		return false
+	}
+	for _, name := range accessDirectives {
+		if def.Directives.ForName(name) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// PermissionsFromContext returns the signed-in user's permissions
+func PermissionsFromContext(ctx context.Context) []string {
+	perms, _ := ctx.Value(ContextKeyPermissions).([]string)
+	return perms
+}
+
+// HasPermissionFromContext reports whether the signed-in user has a permission
+func HasPermissionFromContext(ctx context.Context, perm string) bool {
+	return domain.GrantsPermission(PermissionsFromContext(ctx), perm)
+}
+
+// OwnRecordsOnly reports whether the field is resolved for a user who may
+// only see their own records, such as requests made with their personal API keys
+func OwnRecordsOnly(ctx context.Context) bool {
+	own, _ := ctx.Value(ownRecordsKey{}).(bool)
+	return own
+}
+
+func errUnauthenticated() error {
+	return &gqlerror.Error{
+		Message:    "authentication required",
+		Extensions: map[string]any{"code": "UNAUTHENTICATED"},
+	}
+}
+
+func errForbidden(permission string) error {
+	return &gqlerror.Error{
+		Message:    "permission denied: requires " + permission,
+		Extensions: map[string]any{"code": "FORBIDDEN", "permission": permission},
+	}
+}
@@ -0,0 +1,178 @@
+package resolver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"modelgate/internal/domain"
+	"modelgate/internal/graphql/generated"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// signedIn returns a context for a user holding perms
+func signedIn(perms ...string) context.Context {
+	ctx := context.WithValue(context.Background(), ContextKeyUser, &domain.User{ID: "u1"})
+	return context.WithValue(ctx, ContextKeyPermissions, perms)
+}
+
+// errorCode returns the code extension of a GraphQL error
+func errorCode(err error) string {
+	if gqlErr, ok := err.(*gqlerror.Error); ok {
+		code, _ := gqlErr.Extensions["code"].(string)
+		return code
+	}
+	return ""
+}
+
+func TestHasPermissionDirective(t *testing.T) {
+	own := "usage:read:own"
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		own      *string
+		wantCode string
+		wantOwn  bool
+	}{
+		{"signed out", context.Background(), nil, "UNAUTHENTICATED", false},
+		{"exact permission", signedIn("usage:read"), nil, "", false},
+		{"wildcard permission", signedIn("usage:*"), &own, "", false},
+		{"own records only", signedIn(own), &own, "", true},
+		{"own permission without own declared", signedIn(own), nil, "FORBIDDEN", false},
+		{"no permission", signedIn("keys:read"), &own, "FORBIDDEN", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resolved, ownOnly bool
+			next := func(ctx context.Context) (any, error) {
+				resolved, ownOnly = true, OwnRecordsOnly(ctx)
+				return "ok", nil
+			}
+			_, err := hasPermissionDirective(tt.ctx, nil, next, "usage:read", tt.own)
+			if code := errorCode(err); code != tt.wantCode {
+				t.Fatalf("error code = %q, want %q (%v)", code, tt.wantCode, err)
+			}
+			if resolved != (tt.wantCode == "") || ownOnly != tt.wantOwn {
+				t.Fatalf("resolved = %v, own records only = %v", resolved, ownOnly)
+			}
+		})
+	}
+}
+
+func TestRequireDeclaredAccess(t *testing.T) {
+	undeclared := &ast.FieldDefinition{Name: "secrets"}
+	declared := &ast.FieldDefinition{Name: "me", Directives: ast.DirectiveList{{Name: "authenticated"}}}
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		def      *ast.FieldDefinition
+		resolved bool
+	}{
+		{"field without a directive, regular user", signedIn("keys:read", "usage:*"), undeclared, false},
+		{"field without a directive, signed out", context.Background(), undeclared, false},
+		{"field without a directive, every permission", signedIn("*"), undeclared, true},
+		{"field with a directive", signedIn(), declared, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := graphql.WithResponseContext(tt.ctx, graphql.DefaultErrorPresenter, graphql.DefaultRecover)
+			ctx = graphql.WithRootFieldContext(ctx, &graphql.RootFieldContext{
+				Object: "Query",
+				Field:  graphql.CollectedField{Field: &ast.Field{Name: tt.def.Name, Definition: tt.def}},
+			})
+			resolved := false
+			RequireDeclaredAccess(ctx, func(ctx context.Context) graphql.Marshaler {
+				resolved = true
+				return graphql.MarshalString("ok")
+			})
+			if resolved != tt.resolved {
+				t.Fatalf("resolved = %v, want %v", resolved, tt.resolved)
+			}
+			if errs := graphql.GetErrors(ctx); (len(errs) == 0) != tt.resolved {
+				t.Fatalf("unexpected errors %v", errs)
+			}
+		})
+	}
+}
+
+func TestGrantsPermission(t *testing.T) {
+	tests := []struct {
+		perms []string
+		perm  string
+		want  bool
+	}{
+		{[]string{"*"}, "tenants:write", true},
+		{[]string{"keys:*"}, "keys:revoke", true},
+		{[]string{"keys:*"}, "keystore:read", false},
+		{[]string{"usage:read"}, "usage:read:own", false},
+		{[]string{"usage:read:own"}, "usage:read", false},
+		{[]string{"models:list", "keys:read"}, "keys:read", true},
+		{nil, "keys:read", false},
+	}
+	for _, tt := range tests {
+		if got := domain.GrantsPermission(tt.perms, tt.perm); got != tt.want {
+			t.Errorf("GrantsPermission(%v, %q) = %v, want %v", tt.perms, tt.perm, got, tt.want)
+		}
+	}
+}
+
+func TestDeniedQuery(t *testing.T) {
+	srv := handler.New(generated.NewExecutableSchema(generated.Config{
+		Resolvers:  &Resolver{},
+		Directives: Directives(),
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.AroundRootFields(RequireDeclaredAccess)
+
+	query := func(ctx context.Context, q string) (map[string]json.RawMessage, []string) {
+		body, _ := json.Marshal(map[string]string{"query": q})
+		r := httptest.NewRequest("POST", "/graphql", strings.NewReader(string(body))).WithContext(ctx)
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status %d: %s", w.Code, w.Body)
+		}
+		var resp struct {
+			Data   map[string]json.RawMessage `json:"data"`
+			Errors []struct {
+				Path       []any `json:"path"`
+				Extensions struct {
+					Code string `json:"code"`
+				} `json:"extensions"`
+			} `json:"errors"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		var codes []string
+		for _, e := range resp.Errors {
+			codes = append(codes, e.Extensions.Code)
+		}
+		return resp.Data, codes
+	}
+
+	// A field the user lacks the permission for resolves to null with an
+	// error, and the rest of the query still returns data
+	data, codes := query(signedIn("models:list"), `{ myPermissions tenant(id: "t1") { id } }`)
+	if len(codes) != 1 || codes[0] != "FORBIDDEN" {
+		t.Fatalf("expected one FORBIDDEN error, got %v", codes)
+	}
+	if string(data["tenant"]) != "null" || string(data["myPermissions"]) != `["models:list"]` {
+		t.Fatalf("unexpected data %s %s", data["tenant"], data["myPermissions"])
+	}
+
+	// Signed-out callers are refused as unauthenticated
+	if _, codes := query(context.Background(), `{ tenant(id: "t1") { id } }`); len(codes) != 1 || codes[0] != "UNAUTHENTICATED" {
+		t.Fatalf("expected one UNAUTHENTICATED error, got %v", codes)
+	}
+}
@@ -2,17 +2,40 @@ package resolver
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"log/slog"
+	"path"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"modelgate/internal/audit"
+	"modelgate/internal/cache/semantic"
+	"modelgate/internal/config"
+	"modelgate/internal/configsync"
+	"modelgate/internal/crypto"
 	"modelgate/internal/domain"
+	"modelgate/internal/embedders"
+	"modelgate/internal/experiments"
+	"modelgate/internal/gateway"
 	"modelgate/internal/graphql/model"
+	"modelgate/internal/injection"
+	"modelgate/internal/killswitch"
+	"modelgate/internal/litellm"
 	"modelgate/internal/mcp"
+	"modelgate/internal/ollama"
+	"modelgate/internal/passwords"
 	"modelgate/internal/policy"
+	"modelgate/internal/pricing"
+	"modelgate/internal/provider"
+	"modelgate/internal/replay"
+	"modelgate/internal/retention"
 	"modelgate/internal/storage/postgres"
+	"modelgate/internal/twofactor"
+	"modelgate/internal/usageexport"
 
 	"github.com/google/uuid"
 )
@@ -97,11 +120,20 @@ func convertInputToDomainPolicy(input *model.RolePolicyInput, roleID string) *do
 		// Output Validation
 		if pp.OutputValidation != nil {
 			policy.PromptPolicies.OutputValidation = domain.OutputValidationConfig{
-				Enabled:             pp.OutputValidation.Enabled != nil && *pp.OutputValidation.Enabled,
-				EnforceSchema:       pp.OutputValidation.EnforceSchema != nil && *pp.OutputValidation.EnforceSchema,
-				DetectCodeExecution: pp.OutputValidation.DetectCodeExecution != nil && *pp.OutputValidation.DetectCodeExecution,
-				DetectSecretLeakage: pp.OutputValidation.DetectSecretLeakage != nil && *pp.OutputValidation.DetectSecretLeakage,
-				DetectPIILeakage:    pp.OutputValidation.DetectPIILeakage != nil && *pp.OutputValidation.DetectPIILeakage,
+				Enabled:                   pp.OutputValidation.Enabled != nil && *pp.OutputValidation.Enabled,
+				EnforceSchema:             pp.OutputValidation.EnforceSchema != nil && *pp.OutputValidation.EnforceSchema,
+				DetectCodeExecution:       pp.OutputValidation.DetectCodeExecution != nil && *pp.OutputValidation.DetectCodeExecution,
+				DetectSQLStatements:       pp.OutputValidation.DetectSQLStatements != nil && *pp.OutputValidation.DetectSQLStatements,
+				DetectShellCommands:       pp.OutputValidation.DetectShellCommands != nil && *pp.OutputValidation.DetectShellCommands,
+				DetectHTMLScripts:         pp.OutputValidation.DetectHTMLScripts != nil && *pp.OutputValidation.DetectHTMLScripts,
+				DetectSecretLeakage:       pp.OutputValidation.DetectSecretLeakage != nil && *pp.OutputValidation.DetectSecretLeakage,
+				SecretPatterns:            pp.OutputValidation.SecretPatterns,
+				DetectPIILeakage:          pp.OutputValidation.DetectPIILeakage != nil && *pp.OutputValidation.DetectPIILeakage,
+				DetectSystemPromptLeakage: pp.OutputValidation.DetectSystemPromptLeakage != nil && *pp.OutputValidation.DetectSystemPromptLeakage,
+				StreamWindowChars:         derefInt(pp.OutputValidation.StreamWindowChars),
+			}
+			if pp.OutputValidation.OnViolation != nil {
+				policy.PromptPolicies.OutputValidation.OnViolation = domain.OutputViolationAction(strings.ToLower(string(*pp.OutputValidation.OnViolation)))
 			}
 		}
 
@@ -148,6 +180,9 @@ func convertInputToDomainPolicy(input *model.RolePolicyInput, roleID string) *do
 			TokensPerMinute:   int64(derefInt(rp.TokensPerMinute)),
 			TokensPerHour:     int64(derefInt(rp.TokensPerHour)),
 			TokensPerDay:      int64(derefInt(rp.TokensPerDay)),
+			BurstMultiplier:   derefFloat64(rp.BurstMultiplier),
+			BurstLimit:        derefInt(rp.BurstLimit),
+			BurstTokens:       int64(derefInt(rp.BurstTokens)),
 		}
 	}
 
@@ -163,6 +198,12 @@ func convertInputToDomainPolicy(input *model.RolePolicyInput, roleID string) *do
 			AllowedProviders:    allowedProviders,
 			DefaultModel:        derefStr(mr.DefaultModel),
 			MaxTokensPerRequest: int32(derefInt(mr.MaxTokensPerRequest)),
+
+			RewriteDeprecatedModels: mr.RewriteDeprecatedModels != nil && *mr.RewriteDeprecatedModels,
+
+			AllowedEmbeddingModels: mr.AllowedEmbeddingModels,
+			MaxEmbeddingDimensions: int32(derefInt(mr.MaxEmbeddingDimensions)),
+			MaxEmbeddingInputs:     derefInt(mr.MaxEmbeddingInputs),
 		}
 	}
 
@@ -170,9 +211,21 @@ func convertInputToDomainPolicy(input *model.RolePolicyInput, roleID string) *do
 	if input.McpPolicies != nil {
 		mcp := input.McpPolicies
 		policy.MCPPolicies = domain.MCPPolicies{
-			Enabled:            mcp.Enabled != nil && *mcp.Enabled,
-			AllowToolSearch:    mcp.AllowToolSearch != nil && *mcp.AllowToolSearch,
-			AuditToolExecution: mcp.AuditToolExecution != nil && *mcp.AuditToolExecution,
+			Enabled:                 mcp.Enabled != nil && *mcp.Enabled,
+			AllowToolSearch:         mcp.AllowToolSearch != nil && *mcp.AllowToolSearch,
+			AuditToolExecution:      mcp.AuditToolExecution != nil && *mcp.AuditToolExecution,
+			ListDeniedTools:         mcp.ListDeniedTools != nil && *mcp.ListDeniedTools,
+			MaxInvocationsPerMinute: derefInt(mcp.MaxInvocationsPerMinute),
+			MaxInvocationsPerDay:    derefInt(mcp.MaxInvocationsPerDay),
+			DailyBudgetUSD:          derefFloat64(mcp.DailyBudgetUsd),
+			MonthlyBudgetUSD:        derefFloat64(mcp.MonthlyBudgetUsd),
+		}
+		for _, l := range mcp.ToolLimits {
+			policy.MCPPolicies.ToolLimits = append(policy.MCPPolicies.ToolLimits, domain.MCPToolLimit{
+				Tool:            l.Tool,
+				CooldownSeconds: derefInt(l.CooldownSeconds),
+				CostPerCallUSD:  derefFloat64(l.CostPerCallUsd),
+			})
 		}
 	}
 
@@ -189,6 +242,7 @@ func convertInputToDomainPolicy(input *model.RolePolicyInput, roleID string) *do
 			ExcludedModels:      cp.ExcludedModels,
 			ExcludedPatterns:    cp.ExcludedPatterns,
 			TrackSavings:        cp.TrackSavings != nil && *cp.TrackSavings,
+			AllowBypass:         cp.AllowBypass != nil && *cp.AllowBypass,
 		}
 	}
 
@@ -198,6 +252,7 @@ func convertInputToDomainPolicy(input *model.RolePolicyInput, roleID string) *do
 		policy.RoutingPolicy = domain.RoutingPolicy{
 			Enabled:            rp.Enabled != nil && *rp.Enabled,
 			AllowModelOverride: rp.AllowModelOverride != nil && *rp.AllowModelOverride,
+			CapabilityFallback: rp.CapabilityFallback != nil && *rp.CapabilityFallback,
 		}
 		if rp.Strategy != nil {
 			policy.RoutingPolicy.Strategy = domain.RoutingStrategy(strings.ToLower(string(*rp.Strategy)))
@@ -215,8 +270,11 @@ func convertInputToDomainPolicy(input *model.RolePolicyInput, roleID string) *do
 		if rp.LatencyConfig != nil {
 			lc := rp.LatencyConfig
 			policy.RoutingPolicy.LatencyConfig = &domain.LatencyRoutingConfig{
-				MaxLatencyMs:    derefInt(lc.MaxLatencyMs),
-				PreferredModels: lc.PreferredModels,
+				MaxLatencyMs:      derefInt(lc.MaxLatencyMs),
+				PreferredModels:   lc.PreferredModels,
+				Percentile:        strings.ToLower(derefStr(lc.Percentile)),
+				HysteresisPercent: derefInt(lc.HysteresisPercent),
+				MaxErrorRate:      derefFloat64(lc.MaxErrorRate),
 			}
 		}
 		if rp.WeightedConfig != nil {
@@ -273,33 +331,134 @@ func convertInputToDomainPolicy(input *model.RolePolicyInput, roleID string) *do
 			}
 			policy.ResiliencePolicy.FallbackChain = fallbackChain
 		}
+		if cw := rp.ContextWindow; cw != nil {
+			policy.ResiliencePolicy.ContextWindow = domain.ContextWindowConfig{
+				Enabled:             cw.Enabled != nil && *cw.Enabled,
+				ReserveOutputTokens: derefInt(cw.ReserveOutputTokens),
+				KeepRecentMessages:  derefInt(cw.KeepRecentMessages),
+				LargerModels:        cw.LargerModels,
+			}
+			if cw.Strategy != nil {
+				policy.ResiliencePolicy.ContextWindow.Strategy = domain.ContextStrategy(strings.ToLower(string(*cw.Strategy)))
+			}
+			if cw.SummaryModel != nil {
+				policy.ResiliencePolicy.ContextWindow.SummaryModel = *cw.SummaryModel
+			}
+		}
+		if h := rp.Hedging; h != nil {
+			policy.ResiliencePolicy.Hedging = domain.HedgingConfig{
+				Enabled:  h.Enabled != nil && *h.Enabled,
+				DelayMs:  derefInt(h.DelayMs),
+				Provider: derefStr(h.Provider),
+				Model:    derefStr(h.Model),
+			}
+		}
 	}
 
 	// Extended Policies - Budget
 	if input.BudgetPolicy != nil {
-		bp := input.BudgetPolicy
-		policy.BudgetPolicy = domain.BudgetPolicy{
-			Enabled:           bp.Enabled != nil && *bp.Enabled,
-			DailyLimitUSD:     derefFloat64(bp.DailyLimitUsd),
-			WeeklyLimitUSD:    derefFloat64(bp.WeeklyLimitUsd),
-			MonthlyLimitUSD:   derefFloat64(bp.MonthlyLimitUsd),
-			MaxCostPerRequest: derefFloat64(bp.MaxCostPerRequest),
-			AlertThreshold:    derefFloat64(bp.AlertThreshold),
-			CriticalThreshold: derefFloat64(bp.CriticalThreshold),
-			AlertWebhook:      derefStr(bp.AlertWebhook),
-			AlertEmails:       bp.AlertEmails,
-			AlertSlack:        derefStr(bp.AlertSlack),
-			SoftLimitEnabled:  bp.SoftLimitEnabled != nil && *bp.SoftLimitEnabled,
-			SoftLimitBuffer:   derefFloat64(bp.SoftLimitBuffer),
-		}
-		if bp.OnExceeded != nil {
-			policy.BudgetPolicy.OnExceeded = domain.BudgetExceededAction(strings.ToLower(string(*bp.OnExceeded)))
+		policy.BudgetPolicy = convertBudgetPolicyInput(input.BudgetPolicy)
+	}
+
+	// Extended Policies - Concurrency
+	if input.ConcurrencyPolicy != nil {
+		cp := input.ConcurrencyPolicy
+		policy.ConcurrencyPolicy = domain.ConcurrencyPolicy{
+			Enabled:              cp.Enabled != nil && *cp.Enabled,
+			Priority:             derefInt(cp.Priority),
+			Weight:               derefInt(cp.Weight),
+			MaxConcurrentPerKey:  derefInt(cp.MaxConcurrentPerKey),
+			MaxConcurrentPerRole: derefInt(cp.MaxConcurrentPerRole),
+			QueueWhenLimited:     cp.QueueWhenLimited != nil && *cp.QueueWhenLimited,
+		}
+	}
+
+	// Extended Policies - Network
+	if input.NetworkPolicy != nil {
+		np := input.NetworkPolicy
+		policy.NetworkPolicy = domain.NetworkPolicy{
+			Enabled:           np.Enabled != nil && *np.Enabled,
+			AllowedCIDRs:      np.AllowedCidrs,
+			RequireClientCert: np.RequireClientCert != nil && *np.RequireClientCert,
+		}
+	}
+
+	// Extended Policies - Files
+	if input.FilePolicy != nil {
+		fp := input.FilePolicy
+		policy.FilePolicy = domain.FilePolicy{
+			Enabled:          fp.Enabled != nil && *fp.Enabled,
+			MaxFileSize:      int64(derefInt(fp.MaxFileSize)),
+			AllowedMimeTypes: fp.AllowedMimeTypes,
+			RetentionDays:    derefInt(fp.RetentionDays),
+		}
+	}
+
+	// Extended Policies - Generation parameters
+	if input.GenerationPolicy != nil {
+		gp := input.GenerationPolicy
+		policy.GenerationPolicy = domain.GenerationPolicy{
+			Enabled:            gp.Enabled != nil && *gp.Enabled,
+			DefaultTemperature: toFloat32Ptr(gp.DefaultTemperature),
+			MinTemperature:     toFloat32Ptr(gp.MinTemperature),
+			MaxTemperature:     toFloat32Ptr(gp.MaxTemperature),
+			DefaultTopP:        toFloat32Ptr(gp.DefaultTopP),
+			MaxTopP:            toFloat32Ptr(gp.MaxTopP),
+			DefaultMaxTokens:   int32(derefInt(gp.DefaultMaxTokens)),
+			MaxTokensLimit:     int32(derefInt(gp.MaxTokensLimit)),
+		}
+	}
+
+	// Extended Policies - Output transformation
+	if input.OutputTransformPolicy != nil {
+		op := input.OutputTransformPolicy
+		policy.OutputTransform = domain.OutputTransformPolicy{
+			Enabled:          op.Enabled != nil && *op.Enabled,
+			StripReasoning:   op.StripReasoning != nil && *op.StripReasoning,
+			StripCitations:   op.StripCitations != nil && *op.StripCitations,
+			StripMarkdown:    op.StripMarkdown != nil && *op.StripMarkdown,
+			MaxLength:        derefInt(op.MaxLength),
+			TruncationSuffix: derefStr(op.TruncationSuffix),
+		}
+		for _, r := range op.Rewrites {
+			policy.OutputTransform.Rewrites = append(policy.OutputTransform.Rewrites, domain.OutputRewriteRule{
+				Name:        derefStr(r.Name),
+				Pattern:     r.Pattern,
+				Replacement: r.Replacement,
+			})
 		}
 	}
 
 	return policy
 }
 
+// normalizeNetworkPolicy validates a network policy's CIDRs and stores them in
+// canonical form
+func normalizeNetworkPolicy(policy *domain.RolePolicy) error {
+	cidrs, err := domain.NormalizeCIDRs(policy.NetworkPolicy.AllowedCIDRs)
+	if err != nil {
+		return fmt.Errorf("networkPolicy.allowedCidrs: %w", err)
+	}
+	policy.NetworkPolicy.AllowedCIDRs = cidrs
+	return nil
+}
+
+// validateGenerationPolicy checks a generation policy's bounds
+func validateGenerationPolicy(rp *domain.RolePolicy) error {
+	if err := rp.GenerationPolicy.Validate(); err != nil {
+		return fmt.Errorf("generationPolicy: %w", err)
+	}
+	return nil
+}
+
+// validateOutputTransformPolicy checks an output transform policy's patterns
+func validateOutputTransformPolicy(rp *domain.RolePolicy) error {
+	if err := rp.OutputTransform.Validate(); err != nil {
+		return fmt.Errorf("outputTransformPolicy: %w", err)
+	}
+	return nil
+}
+
 func convertInjectionDetection(input *model.InjectionDetectionInput) domain.InjectionDetectionConfig {
 	cfg := domain.InjectionDetectionConfig{
 		Enabled: input.Enabled != nil && *input.Enabled,
@@ -382,12 +541,18 @@ func convertDomainPolicyToModel(dp *domain.RolePolicy) *model.RolePolicy {
 			AddAntiExtractionSuffix:  pp.SystemPromptProtection.AddAntiExtractionSuffix,
 		},
 		OutputValidation: &model.OutputValidationConfig{
-			Enabled:             pp.OutputValidation.Enabled,
-			EnforceSchema:       pp.OutputValidation.EnforceSchema,
-			DetectCodeExecution: pp.OutputValidation.DetectCodeExecution,
-			DetectSecretLeakage: pp.OutputValidation.DetectSecretLeakage,
-			DetectPIILeakage:    pp.OutputValidation.DetectPIILeakage,
-			OnViolation:         model.OutputViolationAction(strings.ToUpper(string(pp.OutputValidation.OnViolation))),
+			Enabled:                   pp.OutputValidation.Enabled,
+			EnforceSchema:             pp.OutputValidation.EnforceSchema,
+			DetectCodeExecution:       pp.OutputValidation.DetectCodeExecution,
+			DetectSQLStatements:       pp.OutputValidation.DetectSQLStatements,
+			DetectShellCommands:       pp.OutputValidation.DetectShellCommands,
+			DetectHTMLScripts:         pp.OutputValidation.DetectHTMLScripts,
+			DetectSecretLeakage:       pp.OutputValidation.DetectSecretLeakage,
+			SecretPatterns:            pp.OutputValidation.SecretPatterns,
+			DetectPIILeakage:          pp.OutputValidation.DetectPIILeakage,
+			DetectSystemPromptLeakage: pp.OutputValidation.DetectSystemPromptLeakage,
+			StreamWindowChars:         pp.OutputValidation.StreamWindowChars,
+			OnViolation:               model.OutputViolationAction(strings.ToUpper(string(pp.OutputValidation.OnViolation))),
 		},
 	}
 
@@ -410,6 +575,9 @@ func convertDomainPolicyToModel(dp *domain.RolePolicy) *model.RolePolicy {
 		TokensPerMinute:   int(rp.TokensPerMinute),
 		TokensPerHour:     int(rp.TokensPerHour),
 		TokensPerDay:      int(rp.TokensPerDay),
+		BurstMultiplier:   rp.BurstMultiplier,
+		BurstLimit:        rp.BurstLimit,
+		BurstTokens:       int(rp.BurstTokens),
 	}
 
 	// Model Restrictions
@@ -423,6 +591,12 @@ func convertDomainPolicyToModel(dp *domain.RolePolicy) *model.RolePolicy {
 		AllowedProviders:    allowedProviders,
 		DefaultModel:        mr.DefaultModel,
 		MaxTokensPerRequest: int(mr.MaxTokensPerRequest),
+
+		RewriteDeprecatedModels: mr.RewriteDeprecatedModels,
+
+		AllowedEmbeddingModels: mr.AllowedEmbeddingModels,
+		MaxEmbeddingDimensions: int(mr.MaxEmbeddingDimensions),
+		MaxEmbeddingInputs:     mr.MaxEmbeddingInputs,
 	}
 
 	// Extended Policies - Caching
@@ -437,6 +611,7 @@ func convertDomainPolicyToModel(dp *domain.RolePolicy) *model.RolePolicy {
 		ExcludedModels:      cp.ExcludedModels,
 		ExcludedPatterns:    cp.ExcludedPatterns,
 		TrackSavings:        cp.TrackSavings,
+		AllowBypass:         cp.AllowBypass,
 	}
 
 	// Extended Policies - Routing
@@ -445,6 +620,7 @@ func convertDomainPolicyToModel(dp *domain.RolePolicy) *model.RolePolicy {
 		Enabled:            rtp.Enabled,
 		Strategy:           model.RoutingStrategy(strings.ToUpper(string(rtp.Strategy))),
 		AllowModelOverride: rtp.AllowModelOverride,
+		CapabilityFallback: rtp.CapabilityFallback,
 	}
 	if rtp.CostConfig != nil {
 		result.RoutingPolicy.CostConfig = &model.CostRoutingConfig{
@@ -457,8 +633,11 @@ func convertDomainPolicyToModel(dp *domain.RolePolicy) *model.RolePolicy {
 	}
 	if rtp.LatencyConfig != nil {
 		result.RoutingPolicy.LatencyConfig = &model.LatencyRoutingConfig{
-			MaxLatencyMs:    rtp.LatencyConfig.MaxLatencyMs,
-			PreferredModels: rtp.LatencyConfig.PreferredModels,
+			MaxLatencyMs:      rtp.LatencyConfig.MaxLatencyMs,
+			PreferredModels:   nonNilStrings(rtp.LatencyConfig.PreferredModels),
+			Percentile:        rtp.LatencyConfig.Percentile,
+			HysteresisPercent: rtp.LatencyConfig.HysteresisPercent,
+			MaxErrorRate:      rtp.LatencyConfig.MaxErrorRate,
 		}
 	}
 	if rtp.WeightedConfig != nil {
@@ -517,31 +696,111 @@ func convertDomainPolicyToModel(dp *domain.RolePolicy) *model.RolePolicy {
 		}
 		result.ResiliencePolicy.FallbackChain = fallbackChain
 	}
+	cw := rsp.ContextWindow
+	strategy := cw.Strategy
+	if strategy == "" {
+		strategy = domain.ContextStrategyTruncate
+	}
+	result.ResiliencePolicy.ContextWindow = &model.ContextWindowConfig{
+		Enabled:             cw.Enabled,
+		Strategy:            model.ContextStrategy(strings.ToUpper(string(strategy))),
+		ReserveOutputTokens: cw.ReserveOutputTokens,
+		KeepRecentMessages:  cw.KeepRecentMessages,
+		SummaryModel:        cw.SummaryModel,
+		LargerModels:        cw.LargerModels,
+	}
+	if result.ResiliencePolicy.ContextWindow.LargerModels == nil {
+		result.ResiliencePolicy.ContextWindow.LargerModels = []string{}
+	}
+	result.ResiliencePolicy.Hedging = &model.HedgingConfig{
+		Enabled:  rsp.Hedging.Enabled,
+		DelayMs:  rsp.Hedging.DelayMs,
+		Provider: rsp.Hedging.Provider,
+		Model:    rsp.Hedging.Model,
+	}
 
 	// Extended Policies - Budget
-	bp := dp.BudgetPolicy
-	result.BudgetPolicy = &model.BudgetPolicy{
-		Enabled:           bp.Enabled,
-		DailyLimitUsd:     bp.DailyLimitUSD,
-		WeeklyLimitUsd:    bp.WeeklyLimitUSD,
-		MonthlyLimitUsd:   bp.MonthlyLimitUSD,
-		MaxCostPerRequest: bp.MaxCostPerRequest,
-		AlertThreshold:    bp.AlertThreshold,
-		CriticalThreshold: bp.CriticalThreshold,
-		AlertWebhook:      bp.AlertWebhook,
-		AlertEmails:       bp.AlertEmails,
-		AlertSlack:        bp.AlertSlack,
-		OnExceeded:        model.BudgetExceededAction(strings.ToUpper(string(bp.OnExceeded))),
-		SoftLimitEnabled:  bp.SoftLimitEnabled,
-		SoftLimitBuffer:   bp.SoftLimitBuffer,
+	result.BudgetPolicy = convertBudgetPolicyToModel(dp.BudgetPolicy)
+
+	// Extended Policies - Concurrency
+	conc := dp.ConcurrencyPolicy
+	result.ConcurrencyPolicy = &model.ConcurrencyPolicy{
+		Enabled:              conc.Enabled,
+		Priority:             conc.Priority,
+		Weight:               conc.Weight,
+		MaxConcurrentPerKey:  conc.MaxConcurrentPerKey,
+		MaxConcurrentPerRole: conc.MaxConcurrentPerRole,
+		QueueWhenLimited:     conc.QueueWhenLimited,
+	}
+
+	// Extended Policies - Network
+	netPolicy := dp.NetworkPolicy
+	result.NetworkPolicy = &model.NetworkPolicy{
+		Enabled:           netPolicy.Enabled,
+		AllowedCidrs:      nonNilStrings(netPolicy.AllowedCIDRs),
+		RequireClientCert: netPolicy.RequireClientCert,
+	}
+
+	// Extended Policies - Files
+	filePolicy := dp.FilePolicy
+	result.FilePolicy = &model.FilePolicy{
+		Enabled:          filePolicy.Enabled,
+		MaxFileSize:      int(filePolicy.MaxFileSize),
+		AllowedMimeTypes: nonNilStrings(filePolicy.AllowedMimeTypes),
+		RetentionDays:    filePolicy.RetentionDays,
+	}
+
+	// Extended Policies - Generation parameters
+	genPolicy := dp.GenerationPolicy
+	result.GenerationPolicy = &model.GenerationPolicy{
+		Enabled:            genPolicy.Enabled,
+		DefaultTemperature: toFloat64Ptr(genPolicy.DefaultTemperature),
+		MinTemperature:     toFloat64Ptr(genPolicy.MinTemperature),
+		MaxTemperature:     toFloat64Ptr(genPolicy.MaxTemperature),
+		DefaultTopP:        toFloat64Ptr(genPolicy.DefaultTopP),
+		MaxTopP:            toFloat64Ptr(genPolicy.MaxTopP),
+		DefaultMaxTokens:   int(genPolicy.DefaultMaxTokens),
+		MaxTokensLimit:     int(genPolicy.MaxTokensLimit),
+	}
+
+	// Extended Policies - Output transformation
+	transform := dp.OutputTransform
+	result.OutputTransformPolicy = &model.OutputTransformPolicy{
+		Enabled:          transform.Enabled,
+		StripReasoning:   transform.StripReasoning,
+		StripCitations:   transform.StripCitations,
+		StripMarkdown:    transform.StripMarkdown,
+		Rewrites:         []model.OutputRewriteRule{},
+		MaxLength:        transform.MaxLength,
+		TruncationSuffix: transform.TruncationSuffix,
+	}
+	for _, r := range transform.Rewrites {
+		result.OutputTransformPolicy.Rewrites = append(result.OutputTransformPolicy.Rewrites, model.OutputRewriteRule{
+			Name:        optionalStr(r.Name),
+			Pattern:     r.Pattern,
+			Replacement: r.Replacement,
+		})
 	}
 
 	// MCP Policies
 	mcp := dp.MCPPolicies
 	result.McpPolicies = &model.MCPPolicies{
-		Enabled:            mcp.Enabled,
-		AllowToolSearch:    mcp.AllowToolSearch,
-		AuditToolExecution: mcp.AuditToolExecution,
+		Enabled:                 mcp.Enabled,
+		AllowToolSearch:         mcp.AllowToolSearch,
+		AuditToolExecution:      mcp.AuditToolExecution,
+		ListDeniedTools:         mcp.ListDeniedTools,
+		MaxInvocationsPerMinute: mcp.MaxInvocationsPerMinute,
+		MaxInvocationsPerDay:    mcp.MaxInvocationsPerDay,
+		DailyBudgetUsd:          mcp.DailyBudgetUSD,
+		MonthlyBudgetUsd:        mcp.MonthlyBudgetUSD,
+		ToolLimits:              []model.MCPToolLimit{},
+	}
+	for _, l := range mcp.ToolLimits {
+		result.McpPolicies.ToolLimits = append(result.McpPolicies.ToolLimits, model.MCPToolLimit{
+			Tool:            l.Tool,
+			CooldownSeconds: l.CooldownSeconds,
+			CostPerCallUsd:  l.CostPerCallUSD,
+		})
 	}
 
 	return result
@@ -565,6 +824,13 @@ func derefStr(s *string) string {
 	return *s
 }
 
+func derefTime(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
+
 func derefInt(i *int) int {
 	if i == nil {
 		return 0
@@ -579,6 +845,103 @@ func derefFloat64(f *float64) float64 {
 	return *f
 }
 
+func toFloat32Ptr(f *float64) *float32 {
+	if f == nil {
+		return nil
+	}
+	v := float32(*f)
+	return &v
+}
+
+// toFloat64Ptr widens through the decimal form so 0.3 stays 0.3 rather than
+// 0.30000001192092896
+func toFloat64Ptr(f *float32) *float64 {
+	if f == nil {
+		return nil
+	}
+	v, _ := strconv.ParseFloat(strconv.FormatFloat(float64(*f), 'f', -1, 32), 64)
+	return &v
+}
+
+// providerAPIKeyToModel converts a stored provider key, with its recent
+// outcomes on this instance, to the GraphQL model
+func providerAPIKeyToModel(ks *provider.KeySelector, key *provider.ProviderAPIKey, p model.Provider) model.ProviderAPIKey {
+	recentRequests, recentErrorRate := ks.RecentStats(key.ID)
+	result := model.ProviderAPIKey{
+		ID:                 key.ID,
+		Provider:           p,
+		Name:               &key.Name,
+		KeyPrefix:          key.KeyPrefix,
+		CredentialType:     key.CredentialType,
+		Priority:           key.Priority,
+		Enabled:            key.Enabled,
+		HealthScore:        key.HealthScore,
+		SuccessCount:       key.SuccessCount,
+		FailureCount:       key.FailureCount,
+		RateLimitRemaining: key.RateLimitRemaining,
+		RateLimitResetAt:   key.RateLimitResetAt,
+		RequestCount:       int(key.RequestCount),
+		LastUsedAt:         key.LastUsedAt,
+		CreatedAt:          key.CreatedAt,
+		UpdatedAt:          key.UpdatedAt,
+		TrafficPercent:     key.TrafficPercent,
+		RollbackErrorRate:  key.RollbackErrorRate,
+		RolledBackAt:       key.RolledBackAt,
+		RecentRequests:     recentRequests,
+		RecentErrorRate:    recentErrorRate,
+	}
+	if key.RollbackReason != "" {
+		result.RollbackReason = &key.RollbackReason
+	}
+	result.LastValidatedAt = key.LastValidatedAt
+	result.LastValidationOk = key.LastValidationOK
+	result.LastValidationError = optionalStr(key.LastValidationError)
+	result.LastValidationLatencyMs = key.LastValidationLatencyMs
+	result.CooldownUntil = key.CooldownUntil
+	result.CooldownReason = optionalStr(key.CooldownReason)
+	result.InputTokens = int(key.InputTokens)
+	result.OutputTokens = int(key.OutputTokens)
+	result.CostUsd = key.CostUSD
+	return result
+}
+
+// convertKeyStrategyToModel converts a provider's key strategy to its GraphQL enum
+func convertKeyStrategyToModel(s domain.KeyStrategy) model.KeyStrategy {
+	if s == "" || !s.Valid() {
+		return model.KeyStrategyRoundRobin
+	}
+	return model.KeyStrategy(strings.ToUpper(string(s)))
+}
+
+// keyValidationToModel converts a provider key validation to its GraphQL model
+func keyValidationToModel(keyID string, v *provider.KeyValidation) *model.ProviderKeyValidation {
+	result := &model.ProviderKeyValidation{
+		KeyID:        keyID,
+		Valid:        v.Valid,
+		Method:       v.Method,
+		Model:        optionalStr(v.Model),
+		LatencyMs:    v.LatencyMs,
+		Capabilities: nonNilStrings(v.Capabilities),
+		CheckedAt:    v.CheckedAt,
+	}
+	if v.Method == provider.ValidationMethodListModels && v.Valid {
+		result.ModelCount = &v.ModelCount
+	}
+	if v.Error != nil {
+		result.ErrorCode = &v.Error.Code
+		result.Error = &v.Error.Message
+	}
+	return result
+}
+
+// nonNilStrings returns s, or an empty slice for non-null GraphQL list fields
+func nonNilStrings(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
 // GetAuditActor creates an audit.Actor from the context
 func GetAuditActor(ctx context.Context) audit.Actor {
 	userID := GetUserFromContext(ctx)
@@ -598,6 +961,103 @@ func GetAuditActor(ctx context.Context) audit.Actor {
 	}
 }
 
+// loginError is what a failed login tells the user: why they can't log in
+// when their password was right or their account is locked, and nothing
+// more otherwise
+func loginError(err error) error {
+	var locked *passwords.LockedError
+	if errors.As(err, &locked) || errors.Is(err, passwords.ErrPasswordExpired) || errors.Is(err, passwords.ErrPasswordChangeRequired) {
+		return err
+	}
+	return passwords.ErrInvalidCredentials
+}
+
+// verifyTwoFactor is the two-factor step of a login, after the password.
+// Failed codes are recorded in the audit log.
+func (r *mutationResolver) verifyTwoFactor(ctx context.Context, user *postgres.TenantUser, code string) error {
+	if r.twoFactor == nil {
+		return nil
+	}
+	err := r.twoFactor.Verify(ctx, user.ID, user.Role, code)
+	var locked *passwords.LockedError
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, twofactor.ErrInvalidCode) || errors.As(err, &locked):
+		r.auditTwoFactor(ctx, user, domain.AuditActionLogin, err)
+		return err
+	case errors.Is(err, twofactor.ErrCodeRequired) || errors.Is(err, twofactor.ErrEnrollmentRequired):
+		return err
+	}
+	slog.Error("Two-factor check failed", "user_id", user.ID, "error", err)
+	return errors.New("two-factor check failed")
+}
+
+// twoFactorUser authenticates the user of two-factor credentials by their password
+func (r *mutationResolver) twoFactorUser(ctx context.Context, input model.TwoFactorCredentialsInput) (*postgres.TenantUser, error) {
+	if r.twoFactor == nil || r.PGStore == nil {
+		return nil, errors.New("two-factor authentication not configured")
+	}
+	user, err := r.PGStore.TenantStore().AuthenticateUser(ctx, input.Email, input.Password)
+	if err != nil {
+		return nil, loginError(err)
+	}
+	return user, nil
+}
+
+// auditTwoFactor records a two-factor event of a user; a non-nil err records a failure
+func (r *mutationResolver) auditTwoFactor(ctx context.Context, user *postgres.TenantUser, action domain.AuditAction, err error) {
+	entry := audit.LogEntry{
+		TenantSlug:   GetTenantFromContext(ctx),
+		Action:       action,
+		ResourceType: domain.AuditResourceTwoFactor,
+		ResourceID:   user.ID,
+		ResourceName: user.Email,
+		Actor:        audit.Actor{ID: user.ID, Email: user.Email, Type: "user"},
+		IPAddress:    GetIPFromContext(ctx),
+		UserAgent:    GetUserAgentFromContext(ctx),
+	}
+	if err != nil {
+		r.AuditService.LogFailure(ctx, entry, err.Error())
+		return
+	}
+	r.AuditService.LogSuccess(ctx, entry)
+}
+
+// convertTwoFactorSettingsToModel converts two-factor settings to GraphQL model
+func convertTwoFactorSettingsToModel(s domain.TwoFactorSettings) model.TwoFactorSettings {
+	result := model.TwoFactorSettings{
+		RequireForAdmins: s.RequireForAdmins,
+		RequireForAll:    s.RequireForAll,
+		UpdatedBy:        optionalStr(s.UpdatedBy),
+	}
+	if !s.UpdatedAt.IsZero() {
+		result.UpdatedAt = &s.UpdatedAt
+	}
+	return result
+}
+
+// startSession logs a user in
+func (r *mutationResolver) startSession(ctx context.Context, user *postgres.TenantUser) (*model.AuthPayload, error) {
+	session, token, err := r.PGStore.CreateSession(ctx, user.ID, 24*time.Hour)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return &model.AuthPayload{
+		Token: token,
+		User: &model.User{
+			ID:        user.ID,
+			Email:     user.Email,
+			Name:      user.Name,
+			Role:      user.Role,
+			Status:    "active",
+			CreatedAt: session.CreatedAt,
+		},
+		ExpiresAt: session.ExpiresAt,
+	}, nil
+}
+
 // convertTenantUserToModel converts postgres.TenantUser to GraphQL model
 func convertTenantUserToModel(u *postgres.TenantUser) model.User {
 	status := "active"
@@ -612,6 +1072,10 @@ func convertTenantUserToModel(u *postgres.TenantUser) model.User {
 		Role:      u.Role,
 		Status:    status,
 		CreatedAt: u.CreatedAt,
+		APIRoleID: optionalStr(u.APIRoleID),
+
+		DashboardRoleID:  optionalStr(u.DashboardRoleID),
+		TwoFactorEnabled: u.TwoFactorEnabled,
 	}
 
 	if u.LastLoginAt != nil {
@@ -627,6 +1091,35 @@ func convertTenantUserToModel(u *postgres.TenantUser) model.User {
 	return result
 }
 
+// convertAPIKeyWithRoleToModel converts a stored API key to GraphQL model
+func convertAPIKeyWithRoleToModel(k *domain.APIKeyWithRole) model.APIKey {
+	result := model.APIKey{
+		ID:             k.ID,
+		Name:           k.Name,
+		KeyPrefix:      k.KeyPrefix,
+		LastUsedAt:     k.LastUsedAt,
+		CreatedAt:      k.CreatedAt,
+		ExpiresAt:      k.ExpiresAt,
+		IsExpired:      k.ExpiresAt != nil && time.Now().After(*k.ExpiresAt),
+		Revoked:        k.Revoked,
+		AllowedCidrs:   nonNilStrings(k.AllowedCIDRs),
+		ProjectID:      optionalStr(k.ProjectID),
+		ProjectName:    optionalStr(k.ProjectName),
+		Budget:         convertAPIKeyBudgetToModel(k.Budget),
+		CreatedBy:      optionalStr(k.CreatedBy),
+		CreatedByEmail: optionalStr(k.CreatedByEmail),
+		OwnerID:        optionalStr(k.OwnerUserID),
+		OwnerEmail:     optionalStr(k.OwnerEmail),
+	}
+	if k.RoleID != "" {
+		result.Role = &model.Role{ID: k.RoleID, Name: k.RoleName}
+	}
+	if k.GroupID != "" {
+		result.Group = &model.Group{ID: k.GroupID, Name: k.GroupName}
+	}
+	return result
+}
+
 // convertDomainAuditLogToModel converts domain AuditLog to GraphQL model
 func convertDomainAuditLogToModel(log domain.AuditLog) model.AuditLog {
 	result := model.AuditLog{
@@ -750,6 +1243,161 @@ func convertInputToDomainConnectionSettings(input *model.ConnectionSettingsInput
 	return cs
 }
 
+// convertDomainThrottlesToModel converts provider throttles to their GraphQL model
+func convertDomainThrottlesToModel(throttles []domain.ProviderThrottle) []model.ProviderThrottle {
+	out := make([]model.ProviderThrottle, 0, len(throttles))
+	for _, th := range throttles {
+		out = append(out, model.ProviderThrottle{
+			Model:           optionalStr(th.Model),
+			MaxConcurrent:   th.MaxConcurrent,
+			TokensPerMinute: th.TokensPerMinute,
+			MaxQueueWaitSec: th.MaxQueueWaitSec,
+		})
+	}
+	return out
+}
+
+// convertInputToDomainThrottles validates and converts throttle inputs
+func convertInputToDomainThrottles(input []model.ProviderThrottleInput) ([]domain.ProviderThrottle, error) {
+	var throttles []domain.ProviderThrottle
+	seen := make(map[string]bool)
+	for _, in := range input {
+		th := domain.ProviderThrottle{
+			Model:           derefStr(in.Model),
+			MaxConcurrent:   derefInt(in.MaxConcurrent),
+			TokensPerMinute: derefInt(in.TokensPerMinute),
+			MaxQueueWaitSec: derefInt(in.MaxQueueWaitSec),
+		}
+		if th.MaxConcurrent < 0 || th.TokensPerMinute < 0 || th.MaxQueueWaitSec < 0 {
+			return nil, fmt.Errorf("throttle limits cannot be negative")
+		}
+		if seen[th.Model] {
+			if th.Model == "" {
+				return nil, fmt.Errorf("only one provider-wide throttle is allowed")
+			}
+			return nil, fmt.Errorf("duplicate throttle for model %s", th.Model)
+		}
+		seen[th.Model] = true
+		throttles = append(throttles, th)
+	}
+	return throttles, nil
+}
+
+// convertDomainRegionsToModel converts provider regions to their GraphQL
+// model, with whether each is currently failed over
+func convertDomainRegionsToModel(provider domain.Provider, regions []domain.ProviderRegion, gw *gateway.Service) []model.ProviderRegion {
+	out := make([]model.ProviderRegion, 0, len(regions))
+	for _, r := range regions {
+		region := model.ProviderRegion{
+			Name:         r.Name,
+			Priority:     r.Priority,
+			Enabled:      r.Enabled,
+			BaseURL:      optionalStr(r.BaseURL),
+			Region:       optionalStr(r.Region),
+			RegionPrefix: optionalStr(r.RegionPrefix),
+			APIKeyName:   optionalStr(r.APIKeyName),
+		}
+		if gw != nil {
+			if outage, down := gw.RegionOutage(provider, r.Name); down {
+				region.FailedOver = true
+				region.FailedOverReason = optionalStr(outage.Reason)
+				region.FailedOverUntil = &outage.Until
+			}
+		}
+		out = append(out, region)
+	}
+	return out
+}
+
+// convertInputToDomainRegions validates and converts provider region inputs
+func convertInputToDomainRegions(input []model.ProviderRegionInput) ([]domain.ProviderRegion, error) {
+	var regions []domain.ProviderRegion
+	seen := make(map[string]bool)
+	for _, in := range input {
+		r := domain.ProviderRegion{
+			Name:         strings.TrimSpace(in.Name),
+			Priority:     derefInt(in.Priority),
+			Enabled:      ptrToBool(in.Enabled, true),
+			BaseURL:      derefStr(in.BaseURL),
+			Region:       derefStr(in.Region),
+			RegionPrefix: derefStr(in.RegionPrefix),
+			APIKeyName:   derefStr(in.APIKeyName),
+		}
+		if r.Name == "" {
+			return nil, fmt.Errorf("region name is required")
+		}
+		if seen[r.Name] {
+			return nil, fmt.Errorf("duplicate region %s", r.Name)
+		}
+		if r.BaseURL == "" && r.Region == "" {
+			return nil, fmt.Errorf("region %s needs a base URL or an AWS region", r.Name)
+		}
+		seen[r.Name] = true
+		regions = append(regions, r)
+	}
+	return regions, nil
+}
+
+// convertDomainHuggingFaceEndpointsToModel converts Hugging Face Inference
+// Endpoints to their GraphQL model
+func convertDomainHuggingFaceEndpointsToModel(endpoints []domain.HuggingFaceEndpoint) []model.HuggingFaceEndpoint {
+	out := make([]model.HuggingFaceEndpoint, 0, len(endpoints))
+	for _, e := range endpoints {
+		out = append(out, model.HuggingFaceEndpoint{
+			Name:              e.Name,
+			URL:               e.URL,
+			Model:             e.Upstream(),
+			HourlyCostUsd:     e.HourlyCostUSD,
+			ContextWindow:     e.ContextWindow,
+			MaxOutputTokens:   e.MaxOutputTokens,
+			SupportsTools:     e.SupportsTools,
+			SupportsVision:    e.SupportsVision,
+			SupportsStreaming: e.SupportsStreaming,
+			Enabled:           e.Enabled,
+		})
+	}
+	return out
+}
+
+// convertInputToDomainHuggingFaceEndpoints validates and converts Hugging
+// Face Inference Endpoint inputs
+func convertInputToDomainHuggingFaceEndpoints(input []model.HuggingFaceEndpointInput) ([]domain.HuggingFaceEndpoint, error) {
+	var endpoints []domain.HuggingFaceEndpoint
+	seen := make(map[string]bool)
+	for _, in := range input {
+		e := domain.HuggingFaceEndpoint{
+			Name:              strings.TrimSpace(in.Name),
+			URL:               strings.TrimRight(strings.TrimSpace(in.URL), "/"),
+			Model:             strings.TrimSpace(derefStr(in.Model)),
+			HourlyCostUSD:     derefFloat64(in.HourlyCostUsd),
+			ContextWindow:     derefInt(in.ContextWindow),
+			MaxOutputTokens:   derefInt(in.MaxOutputTokens),
+			SupportsTools:     ptrToBool(in.SupportsTools, false),
+			SupportsVision:    ptrToBool(in.SupportsVision, false),
+			SupportsStreaming: ptrToBool(in.SupportsStreaming, true),
+			Enabled:           ptrToBool(in.Enabled, true),
+		}
+		if e.Name == "" {
+			return nil, fmt.Errorf("endpoint name is required")
+		}
+		if strings.Contains(e.Name, "/") {
+			return nil, fmt.Errorf("endpoint name %s must not contain '/'", e.Name)
+		}
+		if seen[e.Name] {
+			return nil, fmt.Errorf("duplicate endpoint %s", e.Name)
+		}
+		if !strings.HasPrefix(e.URL, "http://") && !strings.HasPrefix(e.URL, "https://") {
+			return nil, fmt.Errorf("endpoint %s needs an http(s) URL", e.Name)
+		}
+		if e.HourlyCostUSD < 0 || e.ContextWindow < 0 || e.MaxOutputTokens < 0 {
+			return nil, fmt.Errorf("endpoint %s has a negative cost or limit", e.Name)
+		}
+		seen[e.Name] = true
+		endpoints = append(endpoints, e)
+	}
+	return endpoints, nil
+}
+
 // getPlanCeiling returns connection settings ceiling based on tenant tier
 func getPlanCeiling(tier domain.TenantTier) *model.ConnectionSettings {
 	limits := domain.DefaultPlanLimits[tier]
@@ -928,3 +1576,1362 @@ func (r *mutationResolver) ensureToolSearchAllowed(
 
 	return nil
 }
+
+// =============================================================================
+// Agent Dashboard Conversion Helpers
+// =============================================================================
+
+// convertAgentDashboardStats converts domain.AgentDashboardStats to model.AgentDashboardStats
+func convertAgentDashboardStats(stats *domain.AgentDashboardStats) *model.AgentDashboardStats {
+	if stats == nil {
+		return nil
+	}
+
+	// Convert provider/model usage
+	providerModelUsage := make([]model.ProviderModelUsage, len(stats.ProviderUsage))
+	for i, usage := range stats.ProviderUsage {
+		providerModelUsage[i] = model.ProviderModelUsage{
+			Provider:     usage.Provider,
+			Model:        usage.Model,
+			RequestCount: int(usage.RequestCount),
+			TokenCount:   int(usage.TokenCount),
+			CostUsd:      usage.CostUSD,
+		}
+	}
+
+	// Convert token metrics
+	tokenMetrics := convertTokenMetrics(&stats.TokenMetrics)
+
+	// Convert cache metrics
+	cacheMetrics := convertCacheMetrics(&stats.CacheStats)
+
+	// Convert tool call metrics
+	toolCallMetrics := convertToolCallMetrics(stats.ToolCallStats)
+
+	// Convert risk assessment
+	riskAssessment := convertRiskAssessment(&stats.RiskScore, stats.Violations)
+
+	return &model.AgentDashboardStats{
+		ProviderModelUsage: providerModelUsage,
+		TokenMetrics:       tokenMetrics,
+		CacheMetrics:       cacheMetrics,
+		ToolCallMetrics:    toolCallMetrics,
+		RiskAssessment:     riskAssessment,
+	}
+}
+
+// convertTokenMetrics converts domain.TokenMetrics to model.TokenMetrics
+func convertTokenMetrics(metrics *domain.TokenMetrics) *model.TokenMetrics {
+	if metrics == nil {
+		return nil
+	}
+
+	// Convert ByModel map to slice
+	byModel := make([]model.ModelTokenBreakdown, 0, len(metrics.ByModel))
+	for modelName, breakdown := range metrics.ByModel {
+		byModel = append(byModel, model.ModelTokenBreakdown{
+			Model:          modelName,
+			InputTokens:    int(breakdown.InputTokens),
+			OutputTokens:   int(breakdown.OutputTokens),
+			ThinkingTokens: int(breakdown.ThinkingTokens),
+			CostUsd:        breakdown.CostUSD,
+		})
+	}
+
+	return &model.TokenMetrics{
+		TotalInput:    int(metrics.TotalInput),
+		TotalOutput:   int(metrics.TotalOutput),
+		TotalThinking: int(metrics.TotalThinking),
+		TotalCost:     metrics.TotalCost,
+		ByModel:       byModel,
+	}
+}
+
+// convertCacheMetrics converts domain.CacheStatistics to model.AgentCacheMetrics
+func convertCacheMetrics(cache *domain.CacheStatistics) *model.AgentCacheMetrics {
+	if cache == nil {
+		return nil
+	}
+
+	return &model.AgentCacheMetrics{
+		TotalHits:   int(cache.TotalHits),
+		TotalMisses: int(cache.TotalMisses),
+		HitRate:     cache.HitRate,
+		TokensSaved: int(cache.TokensSaved),
+		CostSaved:   cache.CostSavedUSD,
+	}
+}
+
+// convertToolCallMetrics converts []domain.ToolCallStatistic to model.ToolCallMetrics
+func convertToolCallMetrics(toolCalls []domain.ToolCallStatistic) *model.ToolCallMetrics {
+	if len(toolCalls) == 0 {
+		return &model.ToolCallMetrics{
+			TotalCalls:   0,
+			SuccessCount: 0,
+			FailureCount: 0,
+			SuccessRate:  0,
+			ByTool:       []model.ToolCallBreakdown{},
+		}
+	}
+
+	// Calculate totals
+	var totalCalls, successCount, failureCount int
+	byTool := make([]model.ToolCallBreakdown, len(toolCalls))
+
+	for i, stat := range toolCalls {
+		totalCalls += int(stat.TotalCount)
+		successCount += int(stat.SuccessCount)
+		failureCount += int(stat.FailureCount)
+
+		byTool[i] = model.ToolCallBreakdown{
+			ToolName:     stat.ToolName,
+			SuccessCount: int(stat.SuccessCount),
+			FailureCount: int(stat.FailureCount),
+			TotalCount:   int(stat.TotalCount),
+		}
+	}
+
+	// Calculate success rate
+	var successRate float64
+	if totalCalls > 0 {
+		successRate = (float64(successCount) / float64(totalCalls)) * 100
+	}
+
+	return &model.ToolCallMetrics{
+		TotalCalls:   totalCalls,
+		SuccessCount: successCount,
+		FailureCount: failureCount,
+		SuccessRate:  successRate,
+		ByTool:       byTool,
+	}
+}
+
+// convertRiskAssessment converts domain.RiskAssessment and violations to model.RiskAssessment
+func convertRiskAssessment(risk *domain.RiskAssessment, violations []domain.PolicyViolationStat) *model.RiskAssessment {
+	if risk == nil {
+		return nil
+	}
+
+	// Convert policy violation stats to summaries
+	policyViolations := make([]model.PolicyViolationSummary, len(violations))
+	for i, v := range violations {
+		policyViolations[i] = model.PolicyViolationSummary{
+			ViolationType: v.ViolationType,
+			Count:         int(v.Count),
+			AvgSeverity:   v.AvgSeverity,
+		}
+	}
+
+	// Generate recommendations based on risk level and violations
+	recommendations := generateRecommendations(risk, violations)
+
+	return &model.RiskAssessment{
+		OverallRiskScore: risk.Score,
+		RiskLevel:        risk.Level,
+		PolicyViolations: policyViolations,
+		RecentViolations: []model.PolicyViolationRecord{}, // Empty for now - can be populated later if needed
+		Recommendations:  recommendations,
+	}
+}
+
+// generateRecommendations generates security recommendations based on risk assessment
+func generateRecommendations(risk *domain.RiskAssessment, violations []domain.PolicyViolationStat) []string {
+	recommendations := []string{}
+
+	// Add recommendations based on risk level
+	if risk.Level == "high" || risk.Level == "critical" {
+		recommendations = append(recommendations, "Immediate review required: High risk detected")
+	}
+
+	// Add recommendations based on specific violations
+	violationTypes := make(map[string]bool)
+	for _, v := range violations {
+		violationTypes[v.ViolationType] = true
+	}
+
+	if violationTypes["prompt_injection"] {
+		recommendations = append(recommendations, "Consider implementing stricter prompt validation")
+	}
+	if violationTypes["unauthorized_tool_access"] {
+		recommendations = append(recommendations, "Review and tighten tool access policies")
+	}
+	if violationTypes["rate_limit_exceeded"] {
+		recommendations = append(recommendations, "Increase rate limits or investigate unusual activity")
+	}
+	if violationTypes["cost_limit_exceeded"] {
+		recommendations = append(recommendations, "Review budget allocation or optimize model usage")
+	}
+	if violationTypes["content_filter"] {
+		recommendations = append(recommendations, "Review content filtering policies and training data")
+	}
+
+	// If no specific recommendations, add general one
+	if len(recommendations) == 0 && risk.Violations > 0 {
+		recommendations = append(recommendations, "Monitor policy violations and adjust settings as needed")
+	}
+
+	return recommendations
+}
+
+// =============================================================================
+// DATA RETENTION HELPERS
+// =============================================================================
+
+// retentionPolicyFor returns the effective policy for one table
+func retentionPolicyFor(cfg config.RetentionConfig, overrides map[string]*domain.RetentionOverride, table string) domain.RetentionPolicy {
+	for _, p := range retention.Policies(cfg, overrides) {
+		if p.Table == table {
+			return p
+		}
+	}
+	return domain.RetentionPolicy{Table: table}
+}
+
+func retentionPolicyAuditValue(p domain.RetentionPolicy) map[string]any {
+	return map[string]any{
+		"retention_days":        p.RetentionDays,
+		"archive_before_delete": p.ArchiveBeforeDelete,
+		"enabled":               p.Enabled,
+	}
+}
+
+func convertRetentionPolicyToModel(p domain.RetentionPolicy) *model.RetentionPolicy {
+	return &model.RetentionPolicy{
+		Table:               p.Table,
+		RetentionDays:       p.RetentionDays,
+		ArchiveBeforeDelete: p.ArchiveBeforeDelete,
+		Enabled:             p.Enabled,
+		LastRunAt:           p.LastRunAt,
+		LastArchivedCount:   int(p.LastArchivedCount),
+		LastDeletedCount:    int(p.LastDeletedCount),
+	}
+}
+
+// =============================================================================
+// FEATURE FLAG HELPERS
+// =============================================================================
+
+// roleNamesByID maps role IDs to names for display; missing roles are left out
+func roleNamesByID(ctx context.Context, tenantStore *postgres.TenantStore) map[string]string {
+	names := make(map[string]string)
+	roles, err := tenantStore.ListRoles(ctx)
+	if err != nil {
+		return names
+	}
+	for _, role := range roles {
+		names[role.ID] = role.Name
+	}
+	return names
+}
+
+func featureFlagChangeAuditValue(roleID string, enabled *bool) map[string]any {
+	value := map[string]any{"enabled": enabled}
+	if roleID != "" {
+		value["role_id"] = roleID
+	}
+	return value
+}
+
+// streamTimingToModel returns a usage record's stream timing, nil for
+// requests that weren't streamed
+func streamTimingToModel(record *domain.UsageRecord) (*int, *float64) {
+	if record.TimeToFirstTokenMs == 0 {
+		return nil, nil
+	}
+	ttftMs := int(record.TimeToFirstTokenMs)
+	var tokensPerSecond *float64
+	if record.TokensPerSecond > 0 {
+		tokensPerSecond = &record.TokensPerSecond
+	}
+	return &ttftMs, tokensPerSecond
+}
+
+func optionalStr(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func convertFeatureFlagToModel(f domain.FeatureFlag, roleNames map[string]string) *model.FeatureFlag {
+	overrides := make([]model.FeatureFlagRoleOverride, 0, len(f.RoleOverrides))
+	for roleID, enabled := range f.RoleOverrides {
+		overrides = append(overrides, model.FeatureFlagRoleOverride{
+			RoleID:   roleID,
+			RoleName: optionalStr(roleNames[roleID]),
+			Enabled:  enabled,
+		})
+	}
+	sort.Slice(overrides, func(i, j int) bool { return overrides[i].RoleID < overrides[j].RoleID })
+
+	return &model.FeatureFlag{
+		Key:            f.Key,
+		Description:    f.Description,
+		Enabled:        f.Enabled,
+		DefaultEnabled: f.DefaultEnabled,
+		RoleOverrides:  overrides,
+		UpdatedBy:      optionalStr(f.UpdatedBy),
+		UpdatedAt:      f.UpdatedAt,
+	}
+}
+
+func convertAPIKeyBudgetToModel(b *domain.APIKeyBudget) *model.APIKeyBudget {
+	if b == nil {
+		return nil
+	}
+	return &model.APIKeyBudget{
+		DailySoftLimitUsd:   b.DailySoftLimitUSD,
+		DailyHardLimitUsd:   b.DailyHardLimitUSD,
+		MonthlySoftLimitUsd: b.MonthlySoftLimitUSD,
+		MonthlyHardLimitUsd: b.MonthlyHardLimitUSD,
+		AlertWebhook:        optionalStr(b.AlertWebhook),
+	}
+}
+
+func convertAPIKeyBudgetFromModel(b *model.APIKeyBudget) *domain.APIKeyBudget {
+	if b == nil {
+		return nil
+	}
+	return &domain.APIKeyBudget{
+		DailySoftLimitUSD:   b.DailySoftLimitUsd,
+		DailyHardLimitUSD:   b.DailyHardLimitUsd,
+		MonthlySoftLimitUSD: b.MonthlySoftLimitUsd,
+		MonthlyHardLimitUSD: b.MonthlyHardLimitUsd,
+		AlertWebhook:        derefStr(b.AlertWebhook),
+	}
+}
+
+func convertAPIKeyBudgetInputToDomain(input *model.APIKeyBudgetInput) *domain.APIKeyBudget {
+	if input == nil {
+		return nil
+	}
+	return &domain.APIKeyBudget{
+		DailySoftLimitUSD:   derefFloat64(input.DailySoftLimitUsd),
+		DailyHardLimitUSD:   derefFloat64(input.DailyHardLimitUsd),
+		MonthlySoftLimitUSD: derefFloat64(input.MonthlySoftLimitUsd),
+		MonthlyHardLimitUSD: derefFloat64(input.MonthlyHardLimitUsd),
+		AlertWebhook:        derefStr(input.AlertWebhook),
+	}
+}
+
+func convertKillSwitchToModel(ks *domain.KillSwitch) model.KillSwitch {
+	return model.KillSwitch{
+		Provider:   model.Provider(strings.ToUpper(string(ks.Provider))),
+		Model:      optionalStr(ks.Model),
+		Target:     ks.Target(),
+		Reason:     ks.Reason,
+		DisabledBy: optionalStr(ks.DisabledBy),
+		DisabledAt: ks.DisabledAt,
+		ExpiresAt:  ks.ExpiresAt,
+	}
+}
+
+// killSwitchActor identifies the admin changing a kill switch, for the audit log
+func killSwitchActor(ctx context.Context) killswitch.Actor {
+	actor := GetAuditActor(ctx)
+	return killswitch.Actor{
+		ID:        actor.ID,
+		Email:     actor.Email,
+		IPAddress: GetIPFromContext(ctx),
+		UserAgent: GetUserAgentFromContext(ctx),
+	}
+}
+
+func convertExperimentToModel(e *domain.Experiment) model.Experiment {
+	result := model.Experiment{
+		ID:          e.ID,
+		Name:        e.Name,
+		Description: e.Description,
+		Status:      model.ExperimentStatus(strings.ToUpper(string(e.Status))),
+		Model:       optionalStr(e.Model),
+		RoleIds:     e.RoleIDs,
+		APIKeyIds:   e.APIKeyIDs,
+		CreatedBy:   optionalStr(e.CreatedBy),
+		CreatedAt:   e.CreatedAt,
+		UpdatedAt:   e.UpdatedAt,
+		StartedAt:   e.StartedAt,
+		EndedAt:     e.EndedAt,
+		Variants:    make([]model.ExperimentVariant, len(e.Variants)),
+	}
+	if result.RoleIds == nil {
+		result.RoleIds = []string{}
+	}
+	if result.APIKeyIds == nil {
+		result.APIKeyIds = []string{}
+	}
+	for i, v := range e.Variants {
+		mode := v.SystemPromptMode
+		if mode == "" {
+			mode = domain.SystemPromptReplace
+		}
+		result.Variants[i] = model.ExperimentVariant{
+			Name:             v.Name,
+			Weight:           v.Weight,
+			Model:            optionalStr(v.Model),
+			SystemPrompt:     optionalStr(v.SystemPrompt),
+			SystemPromptMode: model.SystemPromptMode(strings.ToUpper(mode)),
+		}
+	}
+	return result
+}
+
+func convertExperimentInput(input model.ExperimentInput) domain.Experiment {
+	e := domain.Experiment{
+		Name:        strings.TrimSpace(input.Name),
+		Description: derefStr(input.Description),
+		Model:       strings.TrimSpace(derefStr(input.Model)),
+		RoleIDs:     input.RoleIds,
+		APIKeyIDs:   input.APIKeyIds,
+	}
+	for _, v := range input.Variants {
+		variant := domain.ExperimentVariant{
+			Name:         strings.TrimSpace(v.Name),
+			Weight:       v.Weight,
+			Model:        strings.TrimSpace(derefStr(v.Model)),
+			SystemPrompt: derefStr(v.SystemPrompt),
+		}
+		if v.SystemPromptMode != nil {
+			variant.SystemPromptMode = strings.ToLower(string(*v.SystemPromptMode))
+		}
+		e.Variants = append(e.Variants, variant)
+	}
+	return e
+}
+
+func convertExperimentResultsToModel(r *domain.ExperimentResults) *model.ExperimentResults {
+	experiment := convertExperimentToModel(r.Experiment)
+	result := &model.ExperimentResults{
+		Experiment: &experiment,
+		From:       r.From,
+		To:         r.To,
+		Variants:   make([]model.ExperimentVariantStats, len(r.Variants)),
+	}
+	for i, v := range r.Variants {
+		result.Variants[i] = model.ExperimentVariantStats{
+			Variant:               v.Variant,
+			Weight:                v.Weight,
+			Requests:              int(v.Requests),
+			Share:                 v.Share,
+			SuccessRate:           v.SuccessRate,
+			AvgLatencyMs:          v.AvgLatencyMs,
+			P50LatencyMs:          v.P50LatencyMs,
+			P95LatencyMs:          v.P95LatencyMs,
+			AvgTimeToFirstTokenMs: v.AvgTTFTMs,
+			AvgInputTokens:        v.AvgInputTokens,
+			AvgOutputTokens:       v.AvgOutputTokens,
+			TotalCostUsd:          v.TotalCostUSD,
+			CostPerRequest:        v.CostPerRequest,
+			Feedback:              int(v.Feedback),
+			ThumbsUp:              int(v.ThumbsUp),
+			ThumbsDown:            int(v.ThumbsDown),
+			AvgRating:             v.AvgRating,
+		}
+	}
+	return result
+}
+
+// experimentActor identifies the admin changing an experiment, for the audit log
+func experimentActor(ctx context.Context) experiments.Actor {
+	actor := GetAuditActor(ctx)
+	return experiments.Actor{
+		ID:        actor.ID,
+		Email:     actor.Email,
+		IPAddress: GetIPFromContext(ctx),
+		UserAgent: GetUserAgentFromContext(ctx),
+	}
+}
+
+func convertFeedbackToModel(f *domain.Feedback) model.Feedback {
+	result := model.Feedback{
+		ID:            f.ID,
+		RequestID:     f.RequestID,
+		UsageRecordID: f.UsageRecordID,
+		Model:         f.Model,
+		Provider:      string(f.Provider),
+		RoleID:        optionalStr(f.RoleID),
+		APIKeyID:      optionalStr(f.APIKeyID),
+		Rating:        f.Rating,
+		Comment:       optionalStr(f.Comment),
+		SubmittedBy:   optionalStr(f.SubmittedBy),
+		CreatedAt:     f.CreatedAt,
+		UpdatedAt:     f.UpdatedAt,
+	}
+	if f.ThumbsUp != nil {
+		thumbs := model.ThumbsDown
+		if *f.ThumbsUp {
+			thumbs = model.ThumbsUp
+		}
+		result.Thumbs = &thumbs
+	}
+	return result
+}
+
+func convertFeedbackStatsToModel(stats []*domain.FeedbackStats) []model.FeedbackStats {
+	result := make([]model.FeedbackStats, len(stats))
+	for i, st := range stats {
+		result[i] = model.FeedbackStats{
+			Key:        st.Key,
+			Name:       st.Name,
+			Count:      int(st.Count),
+			ThumbsUp:   int(st.ThumbsUp),
+			ThumbsDown: int(st.ThumbsDown),
+			Rated:      int(st.Rated),
+			AvgRating:  st.AvgRating,
+			Comments:   int(st.Comments),
+		}
+	}
+	return result
+}
+
+func convertEmbedderSettingsToModel(e domain.EmbedderSettings) model.EmbedderSettings {
+	result := model.EmbedderSettings{
+		Feature:    model.EmbedderFeature(strings.ToUpper(string(e.Feature))),
+		Type:       e.Type,
+		Model:      e.Model,
+		BaseURL:    optionalStr(e.BaseURL),
+		HasAPIKey:  e.APIKey != "",
+		FromConfig: e.FromConfig,
+		UpdatedBy:  optionalStr(e.UpdatedBy),
+	}
+	if e.Dimensions > 0 {
+		dims := e.Dimensions
+		result.Dimensions = &dims
+	}
+	if !e.UpdatedAt.IsZero() {
+		updatedAt := e.UpdatedAt
+		result.UpdatedAt = &updatedAt
+	}
+	return result
+}
+
+func convertDataPlaneAuditSettingsToModel(s domain.DataPlaneAuditSettings, dropped int64) model.DataPlaneAuditSettings {
+	result := model.DataPlaneAuditSettings{
+		Enabled:       s.Enabled,
+		StoreEvents:   s.StoreEvents,
+		WebhookURL:    optionalStr(s.WebhookURL),
+		DroppedEvents: int(dropped),
+		UpdatedBy:     optionalStr(s.UpdatedBy),
+	}
+	if !s.UpdatedAt.IsZero() {
+		updatedAt := s.UpdatedAt
+		result.UpdatedAt = &updatedAt
+	}
+	return result
+}
+
+func convertAttestationKeyToModel(k domain.AttestationKey) model.AttestationKey {
+	return model.AttestationKey{
+		ID:        k.ID,
+		PublicKey: base64.StdEncoding.EncodeToString(k.PublicKey),
+		Active:    k.RetiredAt == nil,
+		CreatedBy: optionalStr(k.CreatedBy),
+		CreatedAt: k.CreatedAt,
+		RetiredAt: k.RetiredAt,
+	}
+}
+
+func convertModelDeprecationToModel(d *domain.ModelDeprecation) model.ModelDeprecation {
+	return model.ModelDeprecation{
+		Provider:         model.Provider(strings.ToUpper(string(d.Provider))),
+		ModelID:          d.ModelID,
+		SunsetAt:         d.SunsetAt,
+		ReplacementModel: optionalStr(d.ReplacementModel),
+		Notice:           optionalStr(d.Notice),
+		Retired:          d.Sunset(time.Now()),
+		AlertedAt:        d.AlertedAt,
+	}
+}
+
+func convertHealthSampleToModel(h *domain.ProviderHealthSample) model.ProviderHealthSample {
+	return model.ProviderHealthSample{
+		BucketStart:        h.BucketStart,
+		BucketSeconds:      h.BucketSeconds,
+		Provider:           h.Provider,
+		Model:              h.Model,
+		Requests:           int(h.Requests),
+		Failures:           int(h.Failures),
+		ClientErrors:       int(h.ClientErrors),
+		SuccessRate:        h.SuccessRate(),
+		P50LatencyMs:       h.P50LatencyMs,
+		P95LatencyMs:       h.P95LatencyMs,
+		P99LatencyMs:       h.P99LatencyMs,
+		BreakerState:       h.BreakerState,
+		BreakerOpenSeconds: h.BreakerOpenSeconds,
+	}
+}
+
+func convertSLAReportToModel(r *domain.ProviderSLAReport) model.ProviderSLAReport {
+	return model.ProviderSLAReport{
+		Month:               r.Month,
+		Provider:            r.Provider,
+		Model:               r.Model,
+		Requests:            int(r.Requests),
+		Failures:            int(r.Failures),
+		AvailabilityPercent: r.AvailabilityPercent,
+		UptimePercent:       r.UptimePercent,
+		DowntimeMinutes:     r.DowntimeMinutes,
+		BreakerOpenMinutes:  r.BreakerOpenMinutes,
+		P50LatencyMs:        r.P50LatencyMs,
+		P95LatencyMs:        r.P95LatencyMs,
+		P99LatencyMs:        r.P99LatencyMs,
+		TargetPercent:       r.TargetPercent,
+		TargetMet:           r.TargetMet,
+	}
+}
+
+func modelDeprecationAuditValue(d *domain.ModelDeprecation) map[string]any {
+	if d == nil {
+		return nil
+	}
+	return map[string]any{
+		"sunset_at":         d.SunsetAt,
+		"replacement_model": d.ReplacementModel,
+		"notice":            d.Notice,
+	}
+}
+
+func convertCustomDomainToModel(d *domain.CustomDomain) model.CustomDomain {
+	return model.CustomDomain{
+		ID:            d.ID,
+		Hostname:      d.Hostname,
+		TenantSlug:    d.TenantSlug,
+		CertSource:    model.CertSource(strings.ToUpper(string(d.CertSource))),
+		CertExpiresAt: d.CertExpiresAt,
+		Enabled:       d.Enabled,
+		CreatedBy:     optionalStr(d.CreatedBy),
+		CreatedAt:     d.CreatedAt,
+		UpdatedAt:     d.UpdatedAt,
+	}
+}
+
+func customDomainAuditValue(d *domain.CustomDomain) map[string]any {
+	if d == nil {
+		return nil
+	}
+	return map[string]any{
+		"hostname":        d.Hostname,
+		"tenant_slug":     d.TenantSlug,
+		"cert_source":     d.CertSource,
+		"cert_expires_at": d.CertExpiresAt,
+		"enabled":         d.Enabled,
+	}
+}
+
+func convertDataPlaneAuditEventToModel(e domain.DataPlaneAuditEvent) model.DataPlaneAuditEvent {
+	return model.DataPlaneAuditEvent{
+		ID:              e.ID,
+		CreatedAt:       e.CreatedAt,
+		RequestID:       optionalStr(e.RequestID),
+		Method:          e.Method,
+		Path:            e.Path,
+		StatusCode:      e.StatusCode,
+		LatencyMs:       int(e.LatencyMs),
+		APIKeyID:        optionalStr(e.APIKeyID),
+		RoleID:          optionalStr(e.RoleID),
+		ProjectID:       optionalStr(e.ProjectID),
+		ClientIP:        optionalStr(e.ClientIP),
+		Model:           optionalStr(e.Model),
+		ServedModel:     optionalStr(e.ServedModel),
+		PolicyDecision:  optionalStr(e.PolicyDecision),
+		PolicyCode:      optionalStr(e.PolicyCode),
+		ToolsAllowed:    nonNilStrings(e.ToolsAllowed),
+		ToolsRemoved:    nonNilStrings(e.ToolsRemoved),
+		ParamsClamped:   nonNilStrings(e.ParamsClamped),
+		CacheStatus:     optionalStr(string(e.CacheStatus)),
+		RoutingStrategy: optionalStr(string(e.RoutingStrategy)),
+	}
+}
+
+func embedderFeatureFromModel(f model.EmbedderFeature) domain.EmbedderFeature {
+	return domain.EmbedderFeature(strings.ToLower(string(f)))
+}
+
+func convertInjectionPatternToModel(p *domain.InjectionPattern) model.InjectionPattern {
+	return model.InjectionPattern{
+		ID:        p.ID,
+		Text:      p.Text,
+		Category:  model.InjectionCategory(strings.ToUpper(string(p.Category))),
+		Builtin:   p.Builtin,
+		Embedded:  p.Embedded,
+		CreatedBy: optionalStr(p.CreatedBy),
+		CreatedAt: p.CreatedAt,
+	}
+}
+
+func injectionActor(ctx context.Context) injection.Actor {
+	actor := GetAuditActor(ctx)
+	return injection.Actor{
+		ID:        actor.ID,
+		Email:     actor.Email,
+		IPAddress: GetIPFromContext(ctx),
+		UserAgent: GetUserAgentFromContext(ctx),
+	}
+}
+
+func embedderActor(ctx context.Context) embedders.Actor {
+	actor := GetAuditActor(ctx)
+	return embedders.Actor{
+		ID:        actor.ID,
+		Email:     actor.Email,
+		IPAddress: GetIPFromContext(ctx),
+		UserAgent: GetUserAgentFromContext(ctx),
+	}
+}
+
+func convertFeatureFlagChangeToModel(c *domain.FeatureFlagChange) model.FeatureFlagChange {
+	return model.FeatureFlagChange{
+		ID:              c.ID,
+		FlagKey:         c.FlagKey,
+		RoleID:          optionalStr(c.RoleID),
+		RoleName:        optionalStr(c.RoleName),
+		Enabled:         c.Enabled,
+		PreviousEnabled: c.PreviousEnabled,
+		Note:            optionalStr(c.Note),
+		ActorEmail:      optionalStr(c.ActorEmail),
+		CreatedAt:       c.CreatedAt,
+	}
+}
+
+// setModelPriceOverride records an override (or its end) with an audit entry
+func (r *mutationResolver) setModelPriceOverride(ctx context.Context, o pricing.Override) (*model.ModelPrice, error) {
+	tenantSlug := GetTenantFromContext(ctx)
+	if tenantSlug == "" {
+		return nil, fmt.Errorf("tenant context required")
+	}
+	if r.pricing == nil {
+		return nil, fmt.Errorf("pricing not configured")
+	}
+
+	actor := GetAuditActor(ctx)
+	o.ActorEmail = actor.Email
+	auditEntry := audit.LogEntry{
+		TenantSlug:   tenantSlug,
+		Action:       domain.AuditActionUpdate,
+		ResourceType: domain.AuditResourceModelPrice,
+		ResourceID:   string(o.Provider) + "/" + o.ModelID,
+		ResourceName: o.ModelID,
+		Actor:        actor,
+		IPAddress:    GetIPFromContext(ctx),
+		UserAgent:    GetUserAgentFromContext(ctx),
+		OldValue:     modelPriceAuditValue(r.pricing.Price(ctx, o.Provider, o.ModelID, time.Now())),
+	}
+	price, err := r.pricing.SetOverride(ctx, o)
+	if err != nil {
+		r.AuditService.LogFailure(ctx, auditEntry, err.Error())
+		return nil, err
+	}
+
+	auditEntry.NewValue = modelPriceAuditValue(price)
+	auditEntry.Details = map[string]any{"effective_from": price.EffectiveFrom, "reset": price.Reset}
+	if price.Note != "" {
+		auditEntry.Details["note"] = price.Note
+	}
+	r.AuditService.LogSuccess(ctx, auditEntry)
+
+	result := convertModelPriceToModel(price)
+	return &result, nil
+}
+
+func convertModelPriceToModel(p *domain.ModelPrice) model.ModelPrice {
+	out := model.ModelPrice{
+		ID:              optionalStr(p.ID),
+		Provider:        model.Provider(strings.ToUpper(string(p.Provider))),
+		ModelID:         p.ModelID,
+		InputCostPer1m:  p.InputCostPer1M,
+		OutputCostPer1m: p.OutputCostPer1M,
+		Source:          model.PriceSource(strings.ToUpper(string(p.Source))),
+		Reset:           p.Reset,
+		Note:            optionalStr(p.Note),
+		CreatedBy:       optionalStr(p.CreatedBy),
+	}
+	if !p.EffectiveFrom.IsZero() {
+		effectiveFrom := p.EffectiveFrom
+		out.EffectiveFrom = &effectiveFrom
+	}
+	return out
+}
+
+// modelPriceAuditValue describes a price for the audit log
+func modelPriceAuditValue(p *domain.ModelPrice) map[string]any {
+	if p == nil {
+		return nil
+	}
+	return map[string]any{
+		"source":             string(p.Source),
+		"input_cost_per_1m":  p.InputCostPer1M,
+		"output_cost_per_1m": p.OutputCostPer1M,
+	}
+}
+
+// =============================================================================
+// USAGE EXPORT HELPERS
+// =============================================================================
+
+// usageExportDestination describes where exports are written, e.g. s3://bucket/prefix
+func usageExportDestination(cfg config.ObjectStoreConfig) *string {
+	var dest string
+	switch cfg.Type {
+	case "":
+		return nil
+	case "s3":
+		dest = "s3://" + path.Join(cfg.Bucket, cfg.Prefix)
+	case "gcs":
+		dest = "gs://" + path.Join(cfg.Bucket, cfg.Prefix)
+	case "azure":
+		dest = "azure://" + path.Join(cfg.AccessKeyID, cfg.Bucket, cfg.Prefix)
+	default:
+		dest = cfg.Type + ":" + path.Join(cfg.LocalDir, cfg.Prefix)
+	}
+	return &dest
+}
+
+func convertUsageExportStateToModel(s *domain.UsageExportState) model.UsageExportDataset {
+	return model.UsageExportDataset{
+		Dataset:       s.Dataset,
+		ExportedUntil: s.ExportedUntil,
+		LastRunAt:     s.LastRunAt,
+		LastObjectKey: optionalStr(s.LastObjectKey),
+		LastRowCount:  int(s.LastRowCount),
+		LastError:     optionalStr(s.LastError),
+	}
+}
+
+func convertUsageExportResultToModel(r usageexport.Result) model.UsageExportFile {
+	return model.UsageExportFile{
+		Dataset:        r.Dataset,
+		PartitionStart: r.PartitionStart,
+		PartitionEnd:   r.PartitionEnd,
+		ObjectKey:      optionalStr(r.ObjectKey),
+		RowCount:       r.Rows,
+	}
+}
+
+// =============================================================================
+// AUDIT EXPORT HELPERS
+// =============================================================================
+
+func convertAuditLogFilter(filter *model.AuditLogFilter) domain.AuditLogFilter {
+	var f domain.AuditLogFilter
+	if filter == nil {
+		return f
+	}
+	if filter.ResourceType != nil {
+		f.ResourceType = domain.AuditResourceType(strings.ToLower(string(*filter.ResourceType)))
+	}
+	if filter.Action != nil {
+		f.Action = domain.AuditAction(strings.ToLower(string(*filter.Action)))
+	}
+	f.ResourceID = derefStr(filter.ResourceID)
+	f.ActorID = derefStr(filter.ActorID)
+	f.ActorEmail = derefStr(filter.ActorEmail)
+	f.StartTime = derefTime(filter.StartTime)
+	f.EndTime = derefTime(filter.EndTime)
+	return f
+}
+
+func convertAuditExportJobToModel(job *domain.AuditExportJob) model.AuditExportJob {
+	result := model.AuditExportJob{
+		ID:          job.ID,
+		Status:      model.AuditExportStatus(strings.ToUpper(string(job.Status))),
+		Format:      model.AuditExportFormat(strings.ToUpper(job.Format)),
+		RowCount:    int(job.RowCount),
+		SizeBytes:   int(job.SizeBytes),
+		RequestedBy: optionalStr(job.RequestedBy),
+		ObjectKey:   optionalStr(job.ObjectKey),
+		Error:       optionalStr(job.Error),
+		CreatedAt:   job.CreatedAt,
+		CompletedAt: job.CompletedAt,
+		ExpiresAt:   job.ExpiresAt,
+	}
+	if job.Status == domain.AuditExportStatusCompleted && job.ObjectKey == "" &&
+		job.ExpiresAt != nil && time.Now().Before(*job.ExpiresAt) {
+		url := fmt.Sprintf("/exports/audit/%s?token=%s", job.ID, job.DownloadToken)
+		result.DownloadURL = &url
+	}
+	return result
+}
+
+// convertOllamaModelToModel converts an installed Ollama model to GraphQL model
+func convertOllamaModelToModel(m ollama.Model) model.OllamaModel {
+	result := model.OllamaModel{
+		Name:              m.Name,
+		SizeBytes:         int(m.SizeBytes),
+		Digest:            m.Digest,
+		Family:            optionalStr(m.Family),
+		ParameterSize:     optionalStr(m.ParameterSize),
+		QuantizationLevel: optionalStr(m.QuantizationLevel),
+		Loaded:            m.Loaded,
+		VramBytes:         int(m.VRAMBytes),
+		ExpiresAt:         m.ExpiresAt,
+	}
+	if !m.ModifiedAt.IsZero() {
+		modified := m.ModifiedAt
+		result.ModifiedAt = &modified
+	}
+	return result
+}
+
+// convertOllamaPullToModel converts an Ollama pull to GraphQL model
+func convertOllamaPullToModel(p ollama.Pull) model.OllamaPull {
+	return model.OllamaPull{
+		Model:          p.Model,
+		Status:         model.OllamaPullStatus(strings.ToUpper(p.Status)),
+		Detail:         optionalStr(p.Detail),
+		CompletedBytes: int(p.CompletedBytes),
+		TotalBytes:     int(p.TotalBytes),
+		Error:          optionalStr(p.Error),
+		StartedAt:      p.StartedAt,
+		FinishedAt:     p.FinishedAt,
+	}
+}
+
+// convertDigestSubscriptionToModel converts a digest subscription to GraphQL model
+func convertDigestSubscriptionToModel(sub *domain.DigestSubscription, emailEnabled bool) model.DigestSubscription {
+	return model.DigestSubscription{
+		Frequency:    model.DigestFrequency(strings.ToUpper(string(sub.Frequency))),
+		RoleID:       optionalStr(sub.RoleID),
+		LastSentAt:   sub.LastSentAt,
+		EmailEnabled: emailEnabled,
+	}
+}
+
+// convertConfigSyncChangeToModel converts a config sync change to GraphQL model
+func convertConfigSyncChangeToModel(c configsync.Change) model.ConfigSyncChange {
+	fields := c.Fields
+	if fields == nil {
+		fields = []string{}
+	}
+	return model.ConfigSyncChange{Kind: c.Kind, Name: c.Name, Action: c.Action, Fields: fields}
+}
+
+// convertLiteLLMImportReportToModel converts a LiteLLM import report to GraphQL model
+func convertLiteLLMImportReportToModel(report *litellm.Report, dryRun bool) *model.LiteLLMImportResult {
+	result := &model.LiteLLMImportResult{
+		DryRun:   dryRun,
+		Changes:  make([]model.ConfigSyncChange, 0, len(report.Changes)),
+		Aliases:  make([]model.LiteLLMModelAlias, 0, len(report.Aliases)),
+		Warnings: report.Warnings,
+	}
+	for _, c := range report.Changes {
+		result.Changes = append(result.Changes, convertConfigSyncChangeToModel(c))
+	}
+	for name, id := range report.Aliases {
+		result.Aliases = append(result.Aliases, model.LiteLLMModelAlias{Name: name, Model: id})
+	}
+	sort.Slice(result.Aliases, func(i, j int) bool { return result.Aliases[i].Name < result.Aliases[j].Name })
+	if result.Warnings == nil {
+		result.Warnings = []string{}
+	}
+	return result
+}
+
+// convertRegistrationRequestToModel converts a registration request to GraphQL model
+func convertRegistrationRequestToModel(req *domain.RegistrationRequest) *model.RegistrationRequest {
+	result := &model.RegistrationRequest{
+		ID:                req.ID,
+		OrganizationName:  req.OrganizationName,
+		OrganizationEmail: req.OrganizationEmail,
+		AdminName:         req.AdminName,
+		AdminEmail:        req.AdminEmail,
+		Slug:              req.Slug,
+		Status:            req.Status,
+		RequestedAt:       req.RequestedAt,
+		ReviewedAt:        req.ReviewedAt,
+		CreatedAt:         req.CreatedAt,
+		UpdatedAt:         req.UpdatedAt,
+	}
+	if req.PreferredTier != "" {
+		tier := model.TenantTier(strings.ToUpper(string(req.PreferredTier)))
+		result.PreferredTier = &tier
+	}
+	if req.RejectionReason != "" {
+		result.RejectionReason = &req.RejectionReason
+	}
+	if req.UserRole != "" {
+		result.UserRole = &req.UserRole
+	}
+	if req.ReviewedBy != "" {
+		result.ReviewedBy = &req.ReviewedBy
+	}
+	return result
+}
+
+// convertSpendForecastToModel converts a spend forecast to GraphQL model
+func convertSpendForecastToModel(fc *domain.SpendForecast) model.SpendForecast {
+	return model.SpendForecast{
+		ID:           fc.ID,
+		Name:         fc.Name,
+		RoleID:       optionalStr(fc.RoleID),
+		SpentUsd:     fc.SpentUSD,
+		DailyBurnUsd: fc.DailyBurnUSD,
+		ProjectedUsd: fc.ProjectedUSD,
+		BudgetUsd:    fc.BudgetUSD,
+		OverBudget:   fc.OverBudget,
+		ExhaustedAt:  fc.ExhaustedAt,
+	}
+}
+
+// convertCustomModelToModel converts a custom model to GraphQL model
+func convertCustomModelToModel(m *domain.CustomModel) model.CustomModel {
+	return model.CustomModel{
+		ID:                m.ID,
+		ModelID:           m.ModelID,
+		Name:              m.Name,
+		BaseURL:           m.BaseURL,
+		UpstreamModel:     optionalStr(m.UpstreamModel),
+		ContextWindow:     m.ContextWindow,
+		MaxOutputTokens:   m.MaxOutputTokens,
+		InputCostPer1m:    m.InputCostPer1M,
+		OutputCostPer1m:   m.OutputCostPer1M,
+		SupportsTools:     m.SupportsTools,
+		SupportsVision:    m.SupportsVision,
+		SupportsReasoning: m.SupportsReasoning,
+		SupportsStreaming: m.SupportsStreaming,
+		Enabled:           m.Enabled,
+		CreatedAt:         m.CreatedAt,
+		UpdatedAt:         m.UpdatedAt,
+	}
+}
+
+// customModelsChanged drops cached custom provider clients so requests see the
+// new endpoints, and refreshes prices so the declared pricing applies
+func (r *Resolver) customModelsChanged(tenantSlug string) {
+	if r.Gateway != nil {
+		r.Gateway.InvalidateTenantProviderClients(tenantSlug)
+	}
+	if r.pricing != nil {
+		go func() {
+			if _, err := r.pricing.Refresh(context.Background()); err != nil {
+				slog.Warn("Failed to refresh prices after custom model change", "error", err)
+			}
+		}()
+	}
+}
+
+// customModelAuditValue is the audit log value of a custom model
+func customModelAuditValue(m *domain.CustomModel) map[string]any {
+	return map[string]any{
+		"model_id":           m.ModelID,
+		"base_url":           m.BaseURL,
+		"upstream_model":     m.UpstreamModel,
+		"context_window":     m.ContextWindow,
+		"max_output_tokens":  m.MaxOutputTokens,
+		"input_cost_per_1m":  m.InputCostPer1M,
+		"output_cost_per_1m": m.OutputCostPer1M,
+		"supports_tools":     m.SupportsTools,
+		"supports_vision":    m.SupportsVision,
+		"supports_reasoning": m.SupportsReasoning,
+		"supports_streaming": m.SupportsStreaming,
+		"enabled":            m.Enabled,
+	}
+}
+
+// applyCustomModelInput applies the fields set in a GraphQL custom model
+// update to a custom model
+func applyCustomModelInput(m *domain.CustomModel, input model.UpdateCustomModelInput) {
+	if input.ModelID != nil {
+		m.ModelID = *input.ModelID
+	}
+	if input.Name != nil {
+		m.Name = *input.Name
+	}
+	if input.BaseURL != nil {
+		m.BaseURL = *input.BaseURL
+	}
+	if input.UpstreamModel != nil {
+		m.UpstreamModel = *input.UpstreamModel
+	}
+	if input.ContextWindow != nil {
+		m.ContextWindow = *input.ContextWindow
+	}
+	if input.MaxOutputTokens != nil {
+		m.MaxOutputTokens = *input.MaxOutputTokens
+	}
+	if input.InputCostPer1m != nil {
+		m.InputCostPer1M = *input.InputCostPer1m
+	}
+	if input.OutputCostPer1m != nil {
+		m.OutputCostPer1M = *input.OutputCostPer1m
+	}
+	if input.SupportsTools != nil {
+		m.SupportsTools = *input.SupportsTools
+	}
+	if input.SupportsVision != nil {
+		m.SupportsVision = *input.SupportsVision
+	}
+	if input.SupportsReasoning != nil {
+		m.SupportsReasoning = *input.SupportsReasoning
+	}
+	if input.SupportsStreaming != nil {
+		m.SupportsStreaming = *input.SupportsStreaming
+	}
+	if input.Enabled != nil {
+		m.Enabled = *input.Enabled
+	}
+}
+
+// convertBudgetPolicyInput converts a GraphQL budget policy input to domain
+func convertBudgetPolicyInput(bp *model.BudgetPolicyInput) domain.BudgetPolicy {
+	policy := domain.BudgetPolicy{
+		Enabled:                  bp.Enabled != nil && *bp.Enabled,
+		DailyLimitUSD:            derefFloat64(bp.DailyLimitUsd),
+		WeeklyLimitUSD:           derefFloat64(bp.WeeklyLimitUsd),
+		MonthlyLimitUSD:          derefFloat64(bp.MonthlyLimitUsd),
+		MaxCostPerRequest:        derefFloat64(bp.MaxCostPerRequest),
+		MaxTokensPerConversation: int64(derefInt(bp.MaxTokensPerConversation)),
+		MaxCostPerConversation:   derefFloat64(bp.MaxCostPerConversation),
+		AlertThreshold:           derefFloat64(bp.AlertThreshold),
+		CriticalThreshold:        derefFloat64(bp.CriticalThreshold),
+		AlertWebhook:             derefStr(bp.AlertWebhook),
+		AlertEmails:              bp.AlertEmails,
+		AlertSlack:               derefStr(bp.AlertSlack),
+		SoftLimitEnabled:         bp.SoftLimitEnabled != nil && *bp.SoftLimitEnabled,
+		SoftLimitBuffer:          derefFloat64(bp.SoftLimitBuffer),
+	}
+	if bp.OnExceeded != nil {
+		policy.OnExceeded = domain.BudgetExceededAction(strings.ToLower(string(*bp.OnExceeded)))
+	}
+	return policy
+}
+
+// convertBudgetPolicyToModel converts a domain budget policy to GraphQL model
+func convertBudgetPolicyToModel(bp domain.BudgetPolicy) *model.BudgetPolicy {
+	return &model.BudgetPolicy{
+		Enabled:                  bp.Enabled,
+		DailyLimitUsd:            bp.DailyLimitUSD,
+		WeeklyLimitUsd:           bp.WeeklyLimitUSD,
+		MonthlyLimitUsd:          bp.MonthlyLimitUSD,
+		MaxCostPerRequest:        bp.MaxCostPerRequest,
+		MaxTokensPerConversation: int(bp.MaxTokensPerConversation),
+		MaxCostPerConversation:   bp.MaxCostPerConversation,
+		AlertThreshold:           bp.AlertThreshold,
+		CriticalThreshold:        bp.CriticalThreshold,
+		AlertWebhook:             bp.AlertWebhook,
+		AlertEmails:              bp.AlertEmails,
+		AlertSlack:               bp.AlertSlack,
+		OnExceeded:               model.BudgetExceededAction(strings.ToUpper(string(bp.OnExceeded))),
+		SoftLimitEnabled:         bp.SoftLimitEnabled,
+		SoftLimitBuffer:          bp.SoftLimitBuffer,
+	}
+}
+
+// convertProjectToModel converts a project and its current spend to GraphQL model
+func convertProjectToModel(p *domain.Project, spend *domain.ProjectSpend) model.Project {
+	if spend == nil {
+		spend = &domain.ProjectSpend{}
+	}
+	return model.Project{
+		ID:           p.ID,
+		Name:         p.Name,
+		Description:  p.Description,
+		BudgetPolicy: convertBudgetPolicyToModel(p.BudgetPolicy),
+		Spend: &model.ProjectSpend{
+			DailyUsd:   spend.DailyUSD,
+			WeeklyUsd:  spend.WeeklyUSD,
+			MonthlyUsd: spend.MonthlyUSD,
+		},
+		APIKeyCount:    p.APIKeyCount,
+		CreatedBy:      optionalStr(p.CreatedBy),
+		CreatedByEmail: optionalStr(p.CreatedByEmail),
+		CreatedAt:      p.CreatedAt,
+		UpdatedAt:      p.UpdatedAt,
+	}
+}
+
+// convertQuotaPeriodToModel converts a billing period to GraphQL model
+func convertQuotaPeriodToModel(p *domain.QuotaPeriod) model.QuotaPeriod {
+	return model.QuotaPeriod{
+		ID:            p.ID,
+		PeriodStart:   p.PeriodStart,
+		PeriodEnd:     p.PeriodEnd,
+		RequestsUsed:  int(p.RequestsUsed),
+		RequestsLimit: int(p.RequestsLimit),
+		TokensUsed:    int(p.TokensUsed),
+		TokensLimit:   int(p.TokensLimit),
+		CostUsedUsd:   p.CostUsedUSD,
+		CostLimitUsd:  p.CostLimitUSD,
+		Closed:        p.ClosedAt != nil,
+		ClosedAt:      p.ClosedAt,
+	}
+}
+
+// convertReplayResultToModel converts a replay result to GraphQL model
+func convertReplayResultToModel(result *replay.Result) *model.ReplayResult {
+	side := func(s replay.Side) *model.ReplaySide {
+		return &model.ReplaySide{
+			Model:        s.Model,
+			Response:     optionalStr(s.Response),
+			Success:      s.Success,
+			Error:        optionalStr(s.Error),
+			InputTokens:  int(s.InputTokens),
+			OutputTokens: int(s.OutputTokens),
+			CostUsd:      s.CostUSD,
+			LatencyMs:    int(s.LatencyMs),
+		}
+	}
+	diff := make([]model.ReplayDiffRow, len(result.Diff))
+	for i, row := range result.Diff {
+		diff[i] = model.ReplayDiffRow{
+			Kind:  model.ReplayDiffKind(strings.ToUpper(row.Kind)),
+			Left:  row.Left,
+			Right: row.Right,
+		}
+	}
+	return &model.ReplayResult{
+		RecordID:  result.RecordID,
+		RequestID: result.RequestID,
+		Original:  side(result.Original),
+		Replay:    side(result.Replay),
+		Diff:      diff,
+		Identical: result.Identical,
+	}
+}
+
+// convertCacheEntryFilter converts a GraphQL cache entry filter
+func convertCacheEntryFilter(f *model.CacheEntryFilter) semantic.Filter {
+	if f == nil {
+		return semantic.Filter{}
+	}
+	filter := semantic.Filter{
+		RoleID:         derefStr(f.RoleID),
+		Model:          derefStr(f.Model),
+		PromptContains: derefStr(f.PromptContains),
+		SimilarTo:      derefStr(f.SimilarTo),
+		MinSimilarity:  derefFloat64(f.MinSimilarity),
+	}
+	if f.OlderThanSeconds != nil && *f.OlderThanSeconds > 0 {
+		filter.CreatedBefore = time.Now().Add(-time.Duration(*f.OlderThanSeconds) * time.Second)
+	}
+	return filter
+}
+
+// cacheFilterAuditValue records an invalidation filter in the audit log
+func cacheFilterAuditValue(f semantic.Filter) map[string]any {
+	value := map[string]any{}
+	if f.RoleID != "" {
+		value["role_id"] = f.RoleID
+	}
+	if f.Model != "" {
+		value["model"] = f.Model
+	}
+	if !f.CreatedBefore.IsZero() {
+		value["created_before"] = f.CreatedBefore
+	}
+	if f.PromptContains != "" {
+		value["prompt_contains"] = f.PromptContains
+	}
+	if f.SimilarTo != "" {
+		value["similar_to"] = f.SimilarTo
+		value["min_similarity"] = f.MinSimilarity
+	}
+	return value
+}
+
+// convertCacheEntryToModel converts a semantic cache entry to GraphQL model
+func convertCacheEntryToModel(e *semantic.CacheEntry) model.CacheEntry {
+	m := model.CacheEntry{
+		ID:           e.ID,
+		RoleID:       optionalStr(e.RoleID),
+		Model:        e.Model,
+		Provider:     optionalStr(e.Provider),
+		Prompt:       e.Prompt(),
+		InputTokens:  e.InputTokens,
+		OutputTokens: e.OutputTokens,
+		CostUsd:      e.CostUSD,
+		HitCount:     e.HitCount,
+		CreatedAt:    e.CreatedAt,
+		ExpiresAt:    e.ExpiresAt,
+	}
+	if !e.LastHitAt.IsZero() {
+		lastHitAt := e.LastHitAt
+		m.LastHitAt = &lastHitAt
+	}
+	return m
+}
+
+// redactCapturedContent removes captured content that is encrypted at rest
+// from usage record metadata, for callers not allowed to decrypt it
+func redactCapturedContent(metadata map[string]any) {
+	for key, v := range metadata {
+		if sealed, ok := v.(string); ok && crypto.IsSealed(sealed) {
+			delete(metadata, key)
+		}
+	}
+}
+
+// convertPolicyDryRunInput builds the hypothetical chat request of a policy
+// dry run. The caller fills in the RBAC context.
+// convertModelFilterInput converts a models query filter, or returns nil for none
+func convertModelFilterInput(input *model.ModelFilterInput) *domain.ModelFilter {
+	if input == nil {
+		return nil
+	}
+	filter := &domain.ModelFilter{
+		SupportsTools:     ptrToBool(input.SupportsTools, false),
+		SupportsVision:    ptrToBool(input.SupportsVision, false),
+		SupportsReasoning: ptrToBool(input.SupportsReasoning, false),
+		MinContext:        uint32(max(derefInt(input.MinContext), 0)),
+		MaxInputCost:      input.MaxInputCostPer1m,
+		MaxOutputCost:     input.MaxOutputCostPer1m,
+	}
+	if input.Provider != nil {
+		filter.Provider = domain.Provider(strings.ToLower(string(*input.Provider)))
+	}
+	return filter
+}
+
+func convertPolicyDryRunInput(input *model.PolicyDryRunInput) *domain.ChatRequest {
+	req := &domain.ChatRequest{Model: input.Model}
+	for _, m := range input.Messages {
+		req.Messages = append(req.Messages, domain.Message{
+			Role:    m.Role,
+			Content: []domain.ContentBlock{{Type: "text", Text: m.Content}},
+		})
+	}
+	for _, t := range input.Tools {
+		req.Tools = append(req.Tools, domain.Tool{
+			Type: "function",
+			Function: domain.FunctionDefinition{
+				Name:        t.Name,
+				Description: derefStr(t.Description),
+				Parameters:  t.Parameters,
+			},
+		})
+	}
+	if input.MaxTokens != nil {
+		maxTokens := int32(*input.MaxTokens)
+		req.MaxTokens = &maxTokens
+	}
+	req.Temperature = toFloat32Ptr(input.Temperature)
+	req.TopP = toFloat32Ptr(input.TopP)
+	return req
+}
+
+func convertPolicyDryRunResultToModel(result *policy.DryRunResult) *model.PolicyDryRunResult {
+	m := &model.PolicyDryRunResult{
+		Allowed:      result.Allowed,
+		Checks:       make([]model.PolicyDryRunCheck, 0, len(result.Checks)),
+		Tools:        make([]model.PolicyDryRunTool, 0, len(result.Tools)),
+		RemovedTools: result.RemovedTools,
+	}
+	for _, c := range result.Checks {
+		m.Checks = append(m.Checks, model.PolicyDryRunCheck{
+			Role:    optionalStr(c.Role),
+			Policy:  c.Policy,
+			Outcome: c.Outcome,
+			Code:    optionalStr(c.Code),
+			Message: optionalStr(c.Message),
+		})
+	}
+	for _, t := range result.Tools {
+		m.Tools = append(m.Tools, model.PolicyDryRunTool{
+			Name:   t.ToolName,
+			Status: t.Status,
+			Reason: optionalStr(t.Reason),
+		})
+	}
+	if m.RemovedTools == nil {
+		m.RemovedTools = []string{}
+	}
+	return m
+}
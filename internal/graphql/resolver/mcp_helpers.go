@@ -2,6 +2,7 @@ package resolver
 
 import (
 	"strings"
+	"time"
 
 	"modelgate/internal/domain"
 	"modelgate/internal/graphql/model"
@@ -174,14 +175,49 @@ func domainToMCPToolPermissionModel(p *domain.MCPToolPermission) *model.MCPToolP
 	return m
 }
 
+func convertMCPToolApprovalToModel(a *domain.MCPToolApproval) *model.MCPToolApproval {
+	m := &model.MCPToolApproval{
+		ID:               a.ID,
+		RoleID:           a.RoleID,
+		RoleName:         a.RoleName,
+		ServerID:         a.ServerID,
+		ServerName:       a.ServerName,
+		ToolID:           a.ToolID,
+		ToolName:         a.ToolName,
+		Status:           a.Status,
+		RequestCount:     a.RequestCount,
+		FirstRequestedAt: a.FirstRequestedAt,
+		LastRequestedAt:  a.LastRequestedAt,
+		DecidedAt:        a.DecidedAt,
+	}
+	if a.RequestedBy != "" {
+		m.RequestedBy = &a.RequestedBy
+	}
+	if a.Visibility != "" {
+		v := model.MCPToolVisibility(a.Visibility)
+		m.Visibility = &v
+	}
+	if a.DecidedByEmail != "" {
+		m.DecidedByEmail = &a.DecidedByEmail
+	}
+	if a.DecisionReason != "" {
+		m.DecisionReason = &a.DecisionReason
+	}
+	return m
+}
+
 func domainToMCPToolExecutionModel(e *domain.MCPToolExecution) model.MCPToolExecution {
 	m := model.MCPToolExecution{
 		ID:         e.ID,
 		ServerID:   e.ServerID,
 		ToolID:     e.ToolID,
 		Status:     string(e.Status),
+		CostUsd:    e.CostUSD,
 		StartedAt:  e.StartedAt,
 		DurationMs: &e.DurationMs,
+		ServerName: optionalStr(e.ServerName),
+		ToolName:   optionalStr(e.ToolName),
+		APIKeyID:   optionalStr(e.APIKeyID),
 	}
 
 	if e.RoleID != "" {
@@ -206,6 +242,71 @@ func domainToMCPToolExecutionModel(e *domain.MCPToolExecution) model.MCPToolExec
 	return m
 }
 
+// convertMCPExecutionFilter converts a GraphQL MCP tool execution filter
+func convertMCPExecutionFilter(f *model.MCPToolExecutionFilter) domain.MCPToolExecutionFilter {
+	var filter domain.MCPToolExecutionFilter
+	if f == nil {
+		return filter
+	}
+	filter.ServerID = derefStr(f.ServerID)
+	filter.ToolID = derefStr(f.ToolID)
+	filter.RoleID = derefStr(f.RoleID)
+	filter.APIKeyID = derefStr(f.APIKeyID)
+	filter.Status = domain.MCPExecutionStatus(strings.ToUpper(derefStr(f.Status)))
+	if f.StartDate != nil {
+		filter.StartTime = *f.StartDate
+	}
+	if f.EndDate != nil {
+		filter.EndTime = *f.EndDate
+	}
+	return filter
+}
+
+func domainToMCPExecutionStatsModel(s *domain.MCPToolExecutionStats) model.MCPToolExecutionStats {
+	return model.MCPToolExecutionStats{
+		ID:            s.ID,
+		Name:          s.Name,
+		Executions:    int(s.Executions),
+		Errors:        int(s.Errors),
+		Blocked:       int(s.Blocked),
+		ErrorRate:     s.ErrorRate(),
+		AvgDurationMs: s.AvgDurationMs,
+		P95DurationMs: s.P95DurationMs,
+		CostUsd:       s.CostUSD,
+	}
+}
+
+func domainToMCPExecutionStatsModels(stats []*domain.MCPToolExecutionStats) []model.MCPToolExecutionStats {
+	result := make([]model.MCPToolExecutionStats, len(stats))
+	for i, s := range stats {
+		result[i] = domainToMCPExecutionStatsModel(s)
+	}
+	return result
+}
+
+func domainToMCPToolAnalyticsModel(a *domain.MCPToolAnalytics, start, end time.Time) *model.MCPToolAnalytics {
+	totals := domainToMCPExecutionStatsModel(&a.Totals)
+	points := make([]model.MCPToolExecutionPoint, len(a.TimeSeries))
+	for i, p := range a.TimeSeries {
+		points[i] = model.MCPToolExecutionPoint{
+			Timestamp:     p.Timestamp,
+			Executions:    int(p.Executions),
+			Errors:        int(p.Errors),
+			P95DurationMs: p.P95DurationMs,
+		}
+	}
+	return &model.MCPToolAnalytics{
+		PeriodStart:     start,
+		PeriodEnd:       end,
+		Totals:          &totals,
+		ByTool:          domainToMCPExecutionStatsModels(a.ByTool),
+		ByServer:        domainToMCPExecutionStatsModels(a.ByServer),
+		ByRole:          domainToMCPExecutionStatsModels(a.ByRole),
+		TimeSeries:      points,
+		TopFailingTools: domainToMCPExecutionStatsModels(a.TopFailingTools),
+	}
+}
+
 func domainToToolSearchResponse(r *domain.ToolSearchResponse) *model.ToolSearchResponse {
 	tools := make([]model.ToolSearchResult, len(r.Tools))
 	for i, t := range r.Tools {
@@ -5,12 +5,38 @@ package resolver
 import (
 	"context"
 
+	"modelgate/internal/analytics"
+	"modelgate/internal/attestation"
 	"modelgate/internal/audit"
+	"modelgate/internal/auditexport"
+	"modelgate/internal/cache/semantic"
 	"modelgate/internal/config"
+	"modelgate/internal/customdomains"
+	"modelgate/internal/custommodels"
+	"modelgate/internal/dataaudit"
+	"modelgate/internal/deprecation"
+	"modelgate/internal/digest"
 	"modelgate/internal/domain"
+	"modelgate/internal/embedders"
+	"modelgate/internal/experiments"
+	"modelgate/internal/featureflags"
+	"modelgate/internal/feedback"
 	"modelgate/internal/gateway"
+	"modelgate/internal/healthhistory"
+	"modelgate/internal/injection"
+	"modelgate/internal/keybudget"
+	"modelgate/internal/killswitch"
 	"modelgate/internal/mcp"
+	"modelgate/internal/ollama"
+	"modelgate/internal/pricing"
+	"modelgate/internal/projects"
+	"modelgate/internal/quota"
+	"modelgate/internal/registration"
+	"modelgate/internal/replay"
 	"modelgate/internal/storage/postgres"
+	"modelgate/internal/toolapproval"
+	"modelgate/internal/twofactor"
+	"modelgate/internal/usageexport"
 )
 
 // This file will not be regenerated automatically.
@@ -18,11 +44,38 @@ import (
 // It serves as dependency injection for your app, add any dependencies you require here.
 
 type Resolver struct {
-	Config       *config.Config
-	Gateway      *gateway.Service
-	PGStore      *postgres.Store
-	AuditService *audit.Service
-	mcpGateway   *mcp.Gateway
+	Config        *config.Config
+	Gateway       *gateway.Service
+	PGStore       *postgres.Store
+	AuditService  *audit.Service
+	mcpGateway    *mcp.Gateway
+	featureFlags  *featureflags.Service
+	killSwitch    *killswitch.Service
+	experiments   *experiments.Service
+	feedback      *feedback.Service
+	keyBudgets    *keybudget.Service
+	configHolder  *config.Holder
+	pricing       *pricing.Service
+	usageExport   *usageexport.Service
+	auditExport   *auditexport.Service
+	ollama        *ollama.Service
+	customModels  *custommodels.Service
+	semanticCache *semantic.TenantAwareService
+	digests       *digest.Service
+	quota         *quota.Service
+	replay        *replay.Service
+	projects      *projects.Service
+	forecaster    *analytics.Forecaster
+	registration  *registration.Service
+	toolApprovals *toolapproval.Service
+	embedders     *embedders.Service
+	injection     *injection.Service
+	dataAudit     *dataaudit.Service
+	attestation   *attestation.Service
+	deprecations  *deprecation.Service
+	healthHistory *healthhistory.Service
+	domains       *customdomains.Service
+	twoFactor     *twofactor.Service
 }
 
 // NewResolver creates a new resolver with all dependencies
@@ -50,6 +103,8 @@ const (
 	ContextKeyIsAdmin   contextKey = "isAdmin"
 	ContextKeyIPAddress contextKey = "ipAddress"
 	ContextKeyUserAgent contextKey = "userAgent"
+
+	ContextKeyPermissions contextKey = "permissions" // []string of the signed-in user
 )
 
 // GetTenantFromContext retrieves the tenant slug from context
@@ -115,3 +170,138 @@ func IsAdminFromContext(ctx context.Context) bool {
 func (r *Resolver) SetMCPGateway(gw *mcp.Gateway) {
 	r.mcpGateway = gw
 }
+
+// SetFeatureFlags sets the feature flag service for the resolver
+func (r *Resolver) SetFeatureFlags(svc *featureflags.Service) {
+	r.featureFlags = svc
+}
+
+// SetExperiments sets the experiment service for the resolver
+func (r *Resolver) SetExperiments(svc *experiments.Service) {
+	r.experiments = svc
+}
+
+// SetFeedback sets the response feedback service for the resolver
+func (r *Resolver) SetFeedback(svc *feedback.Service) {
+	r.feedback = svc
+}
+
+// SetKillSwitch sets the kill switch service for the resolver
+func (r *Resolver) SetKillSwitch(svc *killswitch.Service) {
+	r.killSwitch = svc
+}
+
+// SetEmbedders sets the per-feature embedder service for the resolver
+func (r *Resolver) SetEmbedders(svc *embedders.Service) {
+	r.embedders = svc
+}
+
+// SetDataAudit sets the data-plane audit service for the resolver
+func (r *Resolver) SetDataAudit(svc *dataaudit.Service) {
+	r.dataAudit = svc
+}
+
+// SetAttestation sets the response attestation service for the resolver
+func (r *Resolver) SetAttestation(svc *attestation.Service) {
+	r.attestation = svc
+}
+
+// SetDeprecations sets the model deprecation service for the resolver
+func (r *Resolver) SetDeprecations(svc *deprecation.Service) {
+	r.deprecations = svc
+}
+
+// SetTwoFactor sets the two-factor authentication service for the resolver
+func (r *Resolver) SetTwoFactor(svc *twofactor.Service) {
+	r.twoFactor = svc
+}
+
+// SetHealthHistory sets the provider health history service for the resolver
+func (r *Resolver) SetHealthHistory(svc *healthhistory.Service) {
+	r.healthHistory = svc
+}
+
+// SetCustomDomains sets the custom domain service for the resolver
+func (r *Resolver) SetCustomDomains(svc *customdomains.Service) {
+	r.domains = svc
+}
+
+// SetInjection sets the injection corpus service for the resolver
+func (r *Resolver) SetInjection(svc *injection.Service) {
+	r.injection = svc
+}
+
+// SetKeyBudgets sets the API key budget service for the resolver
+func (r *Resolver) SetKeyBudgets(svc *keybudget.Service) {
+	r.keyBudgets = svc
+}
+
+// SetPricing sets the pricing service for the resolver
+func (r *Resolver) SetPricing(svc *pricing.Service) {
+	r.pricing = svc
+}
+
+// SetAuditExport sets the audit log export service for the resolver
+func (r *Resolver) SetAuditExport(svc *auditexport.Service) {
+	r.auditExport = svc
+}
+
+// SetOllama sets the Ollama model management service for the resolver
+func (r *Resolver) SetOllama(svc *ollama.Service) {
+	r.ollama = svc
+}
+
+// SetCustomModels sets the custom model service for the resolver
+func (r *Resolver) SetCustomModels(svc *custommodels.Service) {
+	r.customModels = svc
+}
+
+// SetSemanticCache sets the semantic cache used for entry browsing and invalidation
+func (r *Resolver) SetSemanticCache(svc *semantic.TenantAwareService) {
+	r.semanticCache = svc
+}
+
+// SetDigests sets the usage digest service for the resolver
+func (r *Resolver) SetDigests(svc *digest.Service) {
+	r.digests = svc
+}
+
+// SetQuota sets the billing period quota service for the resolver
+func (r *Resolver) SetQuota(svc *quota.Service) {
+	r.quota = svc
+}
+
+// SetForecaster sets the spend forecaster for the resolver
+func (r *Resolver) SetForecaster(f *analytics.Forecaster) {
+	r.forecaster = f
+}
+
+// SetRegistration sets the registration service for the resolver
+func (r *Resolver) SetRegistration(svc *registration.Service) {
+	r.registration = svc
+}
+
+// SetToolApprovals sets the MCP tool approval service for the resolver
+func (r *Resolver) SetToolApprovals(svc *toolapproval.Service) {
+	r.toolApprovals = svc
+}
+
+// SetProjects sets the project service for the resolver
+func (r *Resolver) SetProjects(svc *projects.Service) {
+	r.projects = svc
+}
+
+// SetReplay sets the request replay service for the resolver
+func (r *Resolver) SetReplay(svc *replay.Service) {
+	r.replay = svc
+}
+
+// SetUsageExport sets the scheduled usage export service for the resolver
+func (r *Resolver) SetUsageExport(svc *usageexport.Service) {
+	r.usageExport = svc
+}
+
+// SetConfigHolder sets the live config used by the reloadConfig mutation
+func (r *Resolver) SetConfigHolder(h *config.Holder) {
+	r.configHolder = h
+}
@@ -12,17 +12,51 @@ import (
 	"log"
 	"log/slog"
 	"modelgate/internal/audit"
+	"modelgate/internal/configsync"
+	"modelgate/internal/customdomains"
 	"modelgate/internal/domain"
+	"modelgate/internal/featureflags"
 	"modelgate/internal/graphql/generated"
 	"modelgate/internal/graphql/model"
+	"modelgate/internal/healthhistory"
+	"modelgate/internal/injection"
+	"modelgate/internal/litellm"
 	"modelgate/internal/mcp"
+	"modelgate/internal/passwords"
+	"modelgate/internal/policy"
+	"modelgate/internal/pricing"
+	"modelgate/internal/projects"
 	"modelgate/internal/provider"
+	"modelgate/internal/registration"
+	"modelgate/internal/retention"
+	"modelgate/internal/storage/postgres"
+	"modelgate/internal/toolapproval"
+	"modelgate/internal/twofactor"
+	"modelgate/internal/usageexport"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// BudgetStatus is the resolver for the budgetStatus field.
+func (r *aPIKeyResolver) BudgetStatus(ctx context.Context, obj *model.APIKey) (*model.APIKeyBudgetStatus, error) {
+	if obj.Budget == nil || r.keyBudgets == nil {
+		return nil, nil
+	}
+	key := &domain.APIKey{ID: obj.ID, Name: obj.Name, Budget: convertAPIKeyBudgetFromModel(obj.Budget)}
+	status, err := r.keyBudgets.Status(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return &model.APIKeyBudgetStatus{
+		DailySpentUsd:       status.Spend.DailyUSD,
+		MonthlySpentUsd:     status.Spend.MonthlyUSD,
+		DailyRemainingUsd:   status.DailyRemainingUSD,
+		MonthlyRemainingUsd: status.MonthlyRemainingUSD,
+	}, nil
+}
+
 // Login is the resolver for the login field.
 func (r *mutationResolver) Login(ctx context.Context, input model.LoginInput) (*model.AuthPayload, error) {
 	// Get tenant from context (single-tenant mode uses "default")
@@ -33,27 +67,13 @@ func (r *mutationResolver) Login(ctx context.Context, input model.LoginInput) (*
 
 	user, err := r.PGStore.ValidateUserPassword(ctx, input.Email, input.Password)
 	if err != nil || user == nil {
-		return nil, errors.New("invalid credentials")
+		return nil, loginError(err)
 	}
-
-	// Create session
-	session, token, err := r.PGStore.CreateSession(ctx, user.ID, 24*time.Hour)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create session: %w", err)
+	if err := r.verifyTwoFactor(ctx, user, derefStr(input.Code)); err != nil {
+		return nil, err
 	}
 
-	return &model.AuthPayload{
-		Token: token,
-		User: &model.User{
-			ID:        user.ID,
-			Email:     user.Email,
-			Name:      user.Name,
-			Role:      user.Role,
-			Status:    "active",
-			CreatedAt: session.CreatedAt,
-		},
-		ExpiresAt: session.ExpiresAt,
-	}, nil
+	return r.startSession(ctx, user)
 }
 
 // Logout is the resolver for the logout field.
@@ -61,21 +81,66 @@ func (r *mutationResolver) Logout(ctx context.Context) (bool, error) {
 	return true, nil
 }
 
+// ChangePassword is the resolver for the changePassword field.
+func (r *mutationResolver) ChangePassword(ctx context.Context, input model.ChangePasswordInput) (*model.AuthPayload, error) {
+	if r.PGStore == nil {
+		return nil, errors.New("database not configured")
+	}
+
+	// The code is checked before the password is replaced
+	user, err := r.PGStore.TenantStore().AuthenticateUser(ctx, input.Email, input.CurrentPassword)
+	if err != nil {
+		return nil, loginError(err)
+	}
+	if err := r.verifyTwoFactor(ctx, user, derefStr(input.Code)); err != nil && !errors.Is(err, twofactor.ErrEnrollmentRequired) {
+		return nil, err
+	}
+
+	user, err = r.PGStore.ChangeUserPassword(ctx, input.Email, input.CurrentPassword, input.NewPassword)
+	if err != nil {
+		if passwords.IsRefused(err) {
+			return nil, err
+		}
+		return nil, loginError(err)
+	}
+
+	r.AuditService.LogSuccess(ctx, audit.LogEntry{
+		TenantSlug:   GetTenantFromContext(ctx),
+		Action:       domain.AuditActionUpdate,
+		ResourceType: domain.AuditResourceUser,
+		ResourceID:   user.ID,
+		ResourceName: user.Email,
+		Actor:        audit.Actor{ID: user.ID, Email: user.Email, Type: "user"},
+		IPAddress:    GetIPFromContext(ctx),
+		UserAgent:    GetUserAgentFromContext(ctx),
+		NewValue:     map[string]any{"password_changed": true},
+	})
+
+	return r.startSession(ctx, user)
+}
+
 // CreateRegistrationRequest is the resolver for the createRegistrationRequest field.
-// Not supported in single-tenant open source edition
 func (r *mutationResolver) CreateRegistrationRequest(ctx context.Context, input model.CreateRegistrationRequestInput) (*model.RegistrationRequest, error) {
-	return nil, errors.New("registration requests not supported in single-tenant mode")
-}
-
-// Placeholder to maintain code structure - original returned:
-//
-//	&model.RegistrationRequest{
-//	    ID:                req.ID,
-//	    OrganizationName:  req.OrganizationName,
-//	    OrganizationEmail: req.OrganizationEmail,
-//	    AdminName:         req.AdminName,
-//	    AdminEmail:        req.AdminEmail,
-//
+	if r.registration == nil || !r.registration.Enabled() {
+		return nil, registration.ErrDisabled
+	}
+	in := registration.SubmitInput{
+		OrganizationName:  input.OrganizationName,
+		OrganizationEmail: input.OrganizationEmail,
+		AdminName:         input.AdminName,
+		AdminEmail:        input.AdminEmail,
+		Password:          input.AdminPassword,
+	}
+	if input.PreferredTier != nil {
+		in.PreferredTier = domain.TenantTier(strings.ToLower(string(*input.PreferredTier)))
+	}
+	req, err := r.registration.Submit(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return convertRegistrationRequestToModel(req), nil
+}
+
 // CreateTenant is the resolver for the createTenant field.
 // Not supported in single-tenant open source edition
 func (r *mutationResolver) CreateTenant(ctx context.Context, input model.CreateTenantInput) (*model.Tenant, error) {
@@ -97,13 +162,71 @@ func (r *mutationResolver) DeleteTenant(ctx context.Context, id string) (bool, e
 // ApproveRegistration is the resolver for the approveRegistration field.
 // Not supported in single-tenant open source edition
 func (r *mutationResolver) ApproveRegistration(ctx context.Context, input model.ApproveRegistrationInput) (*model.Tenant, error) {
-	return nil, errors.New("registration not supported in single-tenant mode")
+	if r.registration == nil {
+		return nil, registration.ErrNotFound
+	}
+
+	role := ""
+	if input.Role != nil {
+		role = *input.Role
+	}
+	actor := GetAuditActor(ctx)
+	entry := audit.LogEntry{
+		TenantSlug:   GetTenantFromContext(ctx),
+		Action:       domain.AuditActionApprove,
+		ResourceType: domain.AuditResourceRegistration,
+		ResourceID:   input.RequestID,
+		Actor:        actor,
+		IPAddress:    GetIPFromContext(ctx),
+		UserAgent:    GetUserAgentFromContext(ctx),
+	}
+	req, err := r.registration.Approve(ctx, input.RequestID, role, actor.ID, actor.Email)
+	if err != nil {
+		r.AuditService.LogFailure(ctx, entry, err.Error())
+		return nil, err
+	}
+	entry.ResourceName = req.AdminEmail
+	entry.NewValue = map[string]any{
+		"status":       req.Status,
+		"organization": req.OrganizationName,
+		"role":         req.UserRole,
+	}
+	r.AuditService.LogSuccess(ctx, entry)
+
+	// Single-tenant: the approved user joins the default tenant
+	return r.Query().Tenant(ctx, "default")
 }
 
 // RejectRegistration is the resolver for the rejectRegistration field.
 // Not supported in single-tenant open source edition
 func (r *mutationResolver) RejectRegistration(ctx context.Context, input model.RejectRegistrationInput) (bool, error) {
-	return false, errors.New("registration not supported in single-tenant mode")
+	if r.registration == nil {
+		return false, registration.ErrNotFound
+	}
+
+	actor := GetAuditActor(ctx)
+	entry := audit.LogEntry{
+		TenantSlug:   GetTenantFromContext(ctx),
+		Action:       domain.AuditActionReject,
+		ResourceType: domain.AuditResourceRegistration,
+		ResourceID:   input.RequestID,
+		Actor:        actor,
+		IPAddress:    GetIPFromContext(ctx),
+		UserAgent:    GetUserAgentFromContext(ctx),
+	}
+	req, err := r.registration.Reject(ctx, input.RequestID, input.Reason, actor.Email)
+	if err != nil {
+		r.AuditService.LogFailure(ctx, entry, err.Error())
+		return false, err
+	}
+	entry.ResourceName = req.AdminEmail
+	entry.NewValue = map[string]any{
+		"status":       req.Status,
+		"organization": req.OrganizationName,
+		"reason":       req.RejectionReason,
+	}
+	r.AuditService.LogSuccess(ctx, entry)
+	return true, nil
 }
 
 // UpdateProvider is the resolver for the updateProvider field.
@@ -151,6 +274,10 @@ func (r *mutationResolver) UpdateProvider(ctx context.Context, input model.Updat
 		config.ResourceName = existing.ResourceName
 		config.APIVersion = existing.APIVersion
 		config.ConnectionSettings = existing.ConnectionSettings
+		config.Throttles = existing.Throttles
+		config.Regions = existing.Regions
+		config.KeyStrategy = existing.KeyStrategy
+		config.HuggingFaceEndpoints = existing.HuggingFaceEndpoints
 	}
 
 	// Update with new values
@@ -202,6 +329,30 @@ func (r *mutationResolver) UpdateProvider(ctx context.Context, input model.Updat
 
 		config.ConnectionSettings = newConnSettings
 	}
+	if input.Throttles != nil {
+		throttles, err := convertInputToDomainThrottles(input.Throttles)
+		if err != nil {
+			return nil, err
+		}
+		config.Throttles = throttles
+	}
+	if input.Regions != nil {
+		regions, err := convertInputToDomainRegions(input.Regions)
+		if err != nil {
+			return nil, err
+		}
+		config.Regions = regions
+	}
+	if input.HuggingFaceEndpoints != nil {
+		endpoints, err := convertInputToDomainHuggingFaceEndpoints(input.HuggingFaceEndpoints)
+		if err != nil {
+			return nil, err
+		}
+		config.HuggingFaceEndpoints = endpoints
+	}
+	if input.KeyStrategy != nil {
+		config.KeyStrategy = domain.KeyStrategy(strings.ToLower(string(*input.KeyStrategy)))
+	}
 
 	// Save to database
 	if err := r.PGStore.SaveProviderConfig(ctx, config); err != nil {
@@ -237,19 +388,23 @@ func (r *mutationResolver) UpdateProvider(ctx context.Context, input model.Updat
 	}
 
 	return &model.ProviderConfig{
-		Provider:           input.Provider,
-		Enabled:            input.Enabled,
-		HasAPIKey:          hasAPIKey,
-		HasAccessKeys:      hasAccessKeys,
-		StreamingMode:      streamingMode,
-		BaseURL:            input.BaseURL,
-		Region:             input.Region,
-		ResourceName:       input.ResourceName,
-		APIVersion:         input.APIVersion,
-		RegionPrefix:       input.RegionPrefix,
-		ModelsURL:          input.ModelsURL,
-		ConnectionSettings: convertDomainConnectionSettingsToModel(config.ConnectionSettings),
-		PlanCeiling:        getPlanCeiling(tenant.Tier),
+		Provider:             input.Provider,
+		Enabled:              input.Enabled,
+		HasAPIKey:            hasAPIKey,
+		HasAccessKeys:        hasAccessKeys,
+		StreamingMode:        streamingMode,
+		BaseURL:              input.BaseURL,
+		Region:               input.Region,
+		ResourceName:         input.ResourceName,
+		APIVersion:           input.APIVersion,
+		RegionPrefix:         input.RegionPrefix,
+		ModelsURL:            input.ModelsURL,
+		ConnectionSettings:   convertDomainConnectionSettingsToModel(config.ConnectionSettings),
+		PlanCeiling:          getPlanCeiling(tenant.Tier),
+		Throttles:            convertDomainThrottlesToModel(config.Throttles),
+		Regions:              convertDomainRegionsToModel(config.Provider, config.Regions, r.Gateway),
+		KeyStrategy:          convertKeyStrategyToModel(config.KeyStrategy),
+		HuggingFaceEndpoints: convertDomainHuggingFaceEndpointsToModel(config.HuggingFaceEndpoints),
 	}, nil
 }
 
@@ -306,6 +461,13 @@ func (r *mutationResolver) AddProviderAPIKey(ctx context.Context, input model.Ad
 		return nil, fmt.Errorf("failed to store key: %w", err)
 	}
 
+	// Start as a canary if a traffic share was given
+	if input.TrafficPercent != nil || input.RollbackErrorRate != nil {
+		if err := ks.SetTraffic(ctx, tenantSlug, keyID, input.TrafficPercent, derefFloat64(input.RollbackErrorRate)); err != nil {
+			return nil, fmt.Errorf("failed to set key traffic: %w", err)
+		}
+	}
+
 	// Retrieve the stored key to return
 	keys, err := ks.ListKeys(ctx, tenantSlug, providerDomain)
 	if err != nil {
@@ -315,24 +477,8 @@ func (r *mutationResolver) AddProviderAPIKey(ctx context.Context, input model.Ad
 	// Find the key we just created
 	for _, key := range keys {
 		if key.ID == keyID {
-			return &model.ProviderAPIKey{
-				ID:                 key.ID,
-				Provider:           input.Provider,
-				Name:               &key.Name,
-				KeyPrefix:          key.KeyPrefix,
-				CredentialType:     key.CredentialType,
-				Priority:           key.Priority,
-				Enabled:            key.Enabled,
-				HealthScore:        key.HealthScore,
-				SuccessCount:       key.SuccessCount,
-				FailureCount:       key.FailureCount,
-				RateLimitRemaining: key.RateLimitRemaining,
-				RateLimitResetAt:   key.RateLimitResetAt,
-				RequestCount:       int(key.RequestCount),
-				LastUsedAt:         key.LastUsedAt,
-				CreatedAt:          key.CreatedAt,
-				UpdatedAt:          key.UpdatedAt,
-			}, nil
+			result := providerAPIKeyToModel(ks, key, input.Provider)
+			return &result, nil
 		}
 	}
 
@@ -386,6 +532,43 @@ func (r *mutationResolver) UpdateProviderAPIKey(ctx context.Context, input model
 	}, nil
 }
 
+// SetProviderAPIKeyTraffic is the resolver for the setProviderAPIKeyTraffic field.
+func (r *mutationResolver) SetProviderAPIKeyTraffic(ctx context.Context, id string, trafficPercent *int, rollbackErrorRate *float64) (*model.ProviderAPIKey, error) {
+	tenantSlug := GetTenantFromContext(ctx)
+	if tenantSlug == "" {
+		return nil, errors.New("tenant context required")
+	}
+
+	keySelector := r.Gateway.GetKeySelector()
+	if keySelector == nil {
+		return nil, errors.New("key selector not available")
+	}
+	ks, ok := keySelector.(*provider.KeySelector)
+	if !ok {
+		return nil, errors.New("invalid key selector type")
+	}
+
+	providerDomain, err := ks.KeyProvider(ctx, tenantSlug, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := ks.SetTraffic(ctx, tenantSlug, id, trafficPercent, derefFloat64(rollbackErrorRate)); err != nil {
+		return nil, fmt.Errorf("failed to set key traffic: %w", err)
+	}
+
+	keys, err := ks.ListKeys(ctx, tenantSlug, providerDomain)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve key: %w", err)
+	}
+	for _, key := range keys {
+		if key.ID == id {
+			result := providerAPIKeyToModel(ks, key, model.Provider(strings.ToUpper(string(providerDomain))))
+			return &result, nil
+		}
+	}
+	return nil, errors.New("provider API key not found")
+}
+
 // DeleteProviderAPIKey is the resolver for the deleteProviderAPIKey field.
 func (r *mutationResolver) DeleteProviderAPIKey(ctx context.Context, id string) (bool, error) {
 	tenantSlug := GetTenantFromContext(ctx)
@@ -412,6 +595,20 @@ func (r *mutationResolver) DeleteProviderAPIKey(ctx context.Context, id string)
 	return true, nil
 }
 
+// ValidateProviderAPIKey is the resolver for the validateProviderAPIKey field.
+func (r *mutationResolver) ValidateProviderAPIKey(ctx context.Context, id string) (*model.ProviderKeyValidation, error) {
+	tenantSlug := GetTenantFromContext(ctx)
+	if tenantSlug == "" {
+		return nil, errors.New("tenant context required")
+	}
+
+	result, err := r.Gateway.ValidateProviderKey(ctx, tenantSlug, id)
+	if err != nil {
+		return nil, err
+	}
+	return keyValidationToModel(id, result), nil
+}
+
 // EnableModel is the resolver for the enableModel field.
 func (r *mutationResolver) EnableModel(ctx context.Context, modelID string) (*model.Model, error) {
 	return &model.Model{ID: modelID, Enabled: true}, nil
@@ -467,150 +664,416 @@ func (r *mutationResolver) RefreshProviderModels(ctx context.Context, provider m
 	}, nil
 }
 
-// CreateRole is the resolver for the createRole field.
-func (r *mutationResolver) CreateRole(ctx context.Context, input model.CreateRoleInput) (*model.Role, error) {
+// PullOllamaModel is the resolver for the pullOllamaModel field.
+func (r *mutationResolver) PullOllamaModel(ctx context.Context, name string) (*model.OllamaPull, error) {
 	tenantSlug := GetTenantFromContext(ctx)
 	if tenantSlug == "" {
 		return nil, errors.New("tenant context required")
 	}
-
-	if r.PGStore == nil {
-		return nil, errors.New("database not configured")
+	if r.ollama == nil {
+		return nil, errors.New("ollama management not configured")
 	}
 
-	desc := ""
-	if input.Description != nil {
-		desc = *input.Description
+	auditEntry := audit.LogEntry{
+		TenantSlug:   tenantSlug,
+		Action:       domain.AuditActionCreate,
+		ResourceType: domain.AuditResourceProvider,
+		ResourceID:   string(domain.ProviderOllama),
+		Actor:        GetAuditActor(ctx),
+		IPAddress:    GetIPFromContext(ctx),
+		UserAgent:    GetUserAgentFromContext(ctx),
+		Details:      map[string]any{"operation": "pull_model", "model": name},
 	}
-
-	actor := GetAuditActor(ctx)
-	now := time.Now()
-	role := &domain.Role{
-		ID:             uuid.New().String(),
-		Name:           input.Name,
-		Description:    desc,
-		IsDefault:      input.IsDefault != nil && *input.IsDefault,
-		IsSystem:       false,
-		CreatedBy:      actor.ID,
-		CreatedByEmail: actor.Email,
-		CreatedAt:      now,
-		UpdatedAt:      now,
+	pull, err := r.ollama.Pull(ctx, name)
+	if err != nil {
+		r.AuditService.LogFailure(ctx, auditEntry, err.Error())
+		return nil, err
 	}
+	r.AuditService.LogSuccess(ctx, auditEntry)
 
-	if err := r.PGStore.CreateRole(ctx, role); err != nil {
-		// Audit failure
-		r.AuditService.LogFailure(ctx, audit.LogEntry{
-			TenantSlug:   tenantSlug,
-			Action:       domain.AuditActionCreate,
-			ResourceType: domain.AuditResourceRole,
-			ResourceID:   role.ID,
-			ResourceName: role.Name,
-			Actor:        GetAuditActor(ctx),
-			IPAddress:    GetIPFromContext(ctx),
-			UserAgent:    GetUserAgentFromContext(ctx),
-		}, err.Error())
-		return nil, fmt.Errorf("failed to create role: %w", err)
-	}
+	result := convertOllamaPullToModel(*pull)
+	return &result, nil
+}
 
-	// If a policy is provided, create it
-	if input.Policy != nil {
-		policy := convertInputToDomainPolicy(input.Policy, role.ID)
-		if err := r.PGStore.CreateRolePolicy(ctx, policy); err != nil {
-			return nil, fmt.Errorf("failed to create role policy: %w", err)
-		}
+// DeleteOllamaModel is the resolver for the deleteOllamaModel field.
+func (r *mutationResolver) DeleteOllamaModel(ctx context.Context, name string) (bool, error) {
+	tenantSlug := GetTenantFromContext(ctx)
+	if tenantSlug == "" {
+		return false, errors.New("tenant context required")
+	}
+	if r.ollama == nil {
+		return false, errors.New("ollama management not configured")
 	}
 
-	// Audit success
-	r.AuditService.LogSuccess(ctx, audit.LogEntry{
+	auditEntry := audit.LogEntry{
 		TenantSlug:   tenantSlug,
-		Action:       domain.AuditActionCreate,
-		ResourceType: domain.AuditResourceRole,
-		ResourceID:   role.ID,
-		ResourceName: role.Name,
+		Action:       domain.AuditActionDelete,
+		ResourceType: domain.AuditResourceProvider,
+		ResourceID:   string(domain.ProviderOllama),
 		Actor:        GetAuditActor(ctx),
 		IPAddress:    GetIPFromContext(ctx),
 		UserAgent:    GetUserAgentFromContext(ctx),
-		NewValue: map[string]any{
-			"name":        role.Name,
-			"description": role.Description,
-			"is_default":  role.IsDefault,
-		},
-	})
-
-	return &model.Role{
-		ID:          role.ID,
-		Name:        role.Name,
-		Description: &role.Description,
-		IsDefault:   role.IsDefault,
-		IsSystem:    role.IsSystem,
-		CreatedAt:   role.CreatedAt,
-		UpdatedAt:   role.UpdatedAt,
-	}, nil
+		Details:      map[string]any{"operation": "delete_model", "model": name},
+	}
+	if err := r.ollama.Delete(ctx, name); err != nil {
+		r.AuditService.LogFailure(ctx, auditEntry, err.Error())
+		return false, err
+	}
+	r.AuditService.LogSuccess(ctx, auditEntry)
+	return true, nil
 }
 
-// UpdateRole is the resolver for the updateRole field.
-func (r *mutationResolver) UpdateRole(ctx context.Context, id string, input model.UpdateRoleInput) (*model.Role, error) {
+// RegisterCustomModel is the resolver for the registerCustomModel field.
+func (r *mutationResolver) RegisterCustomModel(ctx context.Context, input model.RegisterCustomModelInput) (*model.CustomModel, error) {
 	tenantSlug := GetTenantFromContext(ctx)
 	if tenantSlug == "" {
 		return nil, errors.New("tenant context required")
 	}
-
-	if r.PGStore == nil {
-		return nil, errors.New("database not configured")
+	if r.customModels == nil {
+		return nil, errors.New("custom models not configured")
 	}
 
-	// Get existing role
-	existingRole, err := r.PGStore.GetRole(ctx, id)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get role: %w", err)
-	}
-	if existingRole == nil {
-		return nil, errors.New("role not found")
+	m := &domain.CustomModel{
+		ModelID:           input.ModelID,
+		Name:              derefStr(input.Name),
+		BaseURL:           input.BaseURL,
+		UpstreamModel:     derefStr(input.UpstreamModel),
+		ContextWindow:     derefInt(input.ContextWindow),
+		MaxOutputTokens:   derefInt(input.MaxOutputTokens),
+		InputCostPer1M:    derefFloat64(input.InputCostPer1m),
+		OutputCostPer1M:   derefFloat64(input.OutputCostPer1m),
+		SupportsTools:     input.SupportsTools != nil && *input.SupportsTools,
+		SupportsVision:    input.SupportsVision != nil && *input.SupportsVision,
+		SupportsReasoning: input.SupportsReasoning != nil && *input.SupportsReasoning,
+		SupportsStreaming: input.SupportsStreaming == nil || *input.SupportsStreaming,
+		Enabled:           input.Enabled == nil || *input.Enabled,
 	}
 
-	// Update fields
-	if input.Name != nil {
-		existingRole.Name = *input.Name
-	}
-	if input.Description != nil {
-		existingRole.Description = *input.Description
-	}
-	if input.IsDefault != nil {
-		existingRole.IsDefault = *input.IsDefault
+	auditEntry := audit.LogEntry{
+		TenantSlug:   tenantSlug,
+		Action:       domain.AuditActionCreate,
+		ResourceType: domain.AuditResourceCustomModel,
+		ResourceName: input.ModelID,
+		Actor:        GetAuditActor(ctx),
+		IPAddress:    GetIPFromContext(ctx),
+		UserAgent:    GetUserAgentFromContext(ctx),
 	}
-	existingRole.UpdatedAt = time.Now()
-
-	if err := r.PGStore.UpdateRole(ctx, existingRole); err != nil {
-		return nil, fmt.Errorf("failed to update role: %w", err)
+	if err := r.customModels.Register(ctx, m); err != nil {
+		r.AuditService.LogFailure(ctx, auditEntry, err.Error())
+		return nil, err
 	}
+	auditEntry.ResourceID = m.ID
+	auditEntry.NewValue = customModelAuditValue(m)
+	r.AuditService.LogSuccess(ctx, auditEntry)
+	r.customModelsChanged(tenantSlug)
 
-	return &model.Role{
-		ID:          existingRole.ID,
-		Name:        existingRole.Name,
-		Description: &existingRole.Description,
-		IsDefault:   existingRole.IsDefault,
-		IsSystem:    existingRole.IsSystem,
-		CreatedAt:   existingRole.CreatedAt,
-		UpdatedAt:   existingRole.UpdatedAt,
-	}, nil
+	result := convertCustomModelToModel(m)
+	return &result, nil
 }
 
-// UpdateRolePolicy is the resolver for the updateRolePolicy field.
-func (r *mutationResolver) UpdateRolePolicy(ctx context.Context, roleID string, input model.RolePolicyInput) (*model.RolePolicy, error) {
+// UpdateCustomModel is the resolver for the updateCustomModel field.
+func (r *mutationResolver) UpdateCustomModel(ctx context.Context, id string, input model.UpdateCustomModelInput) (*model.CustomModel, error) {
 	tenantSlug := GetTenantFromContext(ctx)
 	if tenantSlug == "" {
 		return nil, errors.New("tenant context required")
 	}
+	if r.customModels == nil {
+		return nil, errors.New("custom models not configured")
+	}
 
-	if r.PGStore == nil {
-		return nil, errors.New("database not configured")
+	m, err := r.customModels.Get(ctx, id)
+	if err != nil {
+		return nil, err
 	}
+	old := *m
+	applyCustomModelInput(m, input)
 
-	// Get existing policy for audit
-	existingPolicy, _ := r.PGStore.GetRolePolicy(ctx, roleID)
-	var oldValue map[string]any
-	if existingPolicy != nil {
-		oldValue = map[string]any{
+	auditEntry := audit.LogEntry{
+		TenantSlug:   tenantSlug,
+		Action:       domain.AuditActionUpdate,
+		ResourceType: domain.AuditResourceCustomModel,
+		ResourceID:   id,
+		ResourceName: old.ModelID,
+		Actor:        GetAuditActor(ctx),
+		IPAddress:    GetIPFromContext(ctx),
+		UserAgent:    GetUserAgentFromContext(ctx),
+		OldValue:     customModelAuditValue(&old),
+	}
+	if err := r.customModels.Update(ctx, m); err != nil {
+		r.AuditService.LogFailure(ctx, auditEntry, err.Error())
+		return nil, err
+	}
+	auditEntry.NewValue = customModelAuditValue(m)
+	r.AuditService.LogSuccess(ctx, auditEntry)
+	r.customModelsChanged(tenantSlug)
+
+	result := convertCustomModelToModel(m)
+	return &result, nil
+}
+
+// DeleteCustomModel is the resolver for the deleteCustomModel field.
+func (r *mutationResolver) DeleteCustomModel(ctx context.Context, id string) (bool, error) {
+	tenantSlug := GetTenantFromContext(ctx)
+	if tenantSlug == "" {
+		return false, errors.New("tenant context required")
+	}
+	if r.customModels == nil {
+		return false, errors.New("custom models not configured")
+	}
+
+	auditEntry := audit.LogEntry{
+		TenantSlug:   tenantSlug,
+		Action:       domain.AuditActionDelete,
+		ResourceType: domain.AuditResourceCustomModel,
+		ResourceID:   id,
+		Actor:        GetAuditActor(ctx),
+		IPAddress:    GetIPFromContext(ctx),
+		UserAgent:    GetUserAgentFromContext(ctx),
+	}
+	if old, err := r.customModels.Get(ctx, id); err == nil {
+		auditEntry.ResourceName = old.ModelID
+		auditEntry.OldValue = customModelAuditValue(old)
+	}
+	if err := r.customModels.Delete(ctx, id); err != nil {
+		r.AuditService.LogFailure(ctx, auditEntry, err.Error())
+		return false, err
+	}
+	r.AuditService.LogSuccess(ctx, auditEntry)
+	r.customModelsChanged(tenantSlug)
+	return true, nil
+}
+
+// InvalidateCache is the resolver for the invalidateCache field.
+func (r *mutationResolver) InvalidateCache(ctx context.Context, filter model.CacheEntryFilter) (int, error) {
+	tenantSlug := GetTenantFromContext(ctx)
+	if tenantSlug == "" {
+		return 0, errors.New("tenant context required")
+	}
+	if r.semanticCache == nil {
+		return 0, errors.New("semantic cache not configured")
+	}
+
+	f := convertCacheEntryFilter(&filter)
+	auditEntry := audit.LogEntry{
+		TenantSlug:   tenantSlug,
+		Action:       domain.AuditActionDelete,
+		ResourceType: domain.AuditResourceCacheEntry,
+		Actor:        GetAuditActor(ctx),
+		IPAddress:    GetIPFromContext(ctx),
+		UserAgent:    GetUserAgentFromContext(ctx),
+		OldValue:     cacheFilterAuditValue(f),
+	}
+	removed, err := r.semanticCache.InvalidateMatching(ctx, f)
+	if err != nil {
+		r.AuditService.LogFailure(ctx, auditEntry, err.Error())
+		return 0, err
+	}
+	auditEntry.NewValue = map[string]any{"removed": removed}
+	r.AuditService.LogSuccess(ctx, auditEntry)
+	return int(removed), nil
+}
+
+// DeleteCacheEntry is the resolver for the deleteCacheEntry field.
+func (r *mutationResolver) DeleteCacheEntry(ctx context.Context, id string) (bool, error) {
+	tenantSlug := GetTenantFromContext(ctx)
+	if tenantSlug == "" {
+		return false, errors.New("tenant context required")
+	}
+	if r.semanticCache == nil {
+		return false, errors.New("semantic cache not configured")
+	}
+
+	auditEntry := audit.LogEntry{
+		TenantSlug:   tenantSlug,
+		Action:       domain.AuditActionDelete,
+		ResourceType: domain.AuditResourceCacheEntry,
+		ResourceID:   id,
+		Actor:        GetAuditActor(ctx),
+		IPAddress:    GetIPFromContext(ctx),
+		UserAgent:    GetUserAgentFromContext(ctx),
+	}
+	deleted, err := r.semanticCache.InvalidateByID(ctx, id)
+	if err != nil {
+		r.AuditService.LogFailure(ctx, auditEntry, err.Error())
+		return false, err
+	}
+	if !deleted {
+		return false, errors.New("cache entry not found")
+	}
+	r.AuditService.LogSuccess(ctx, auditEntry)
+	return true, nil
+}
+
+// CreateRole is the resolver for the createRole field.
+func (r *mutationResolver) CreateRole(ctx context.Context, input model.CreateRoleInput) (*model.Role, error) {
+	tenantSlug := GetTenantFromContext(ctx)
+	if tenantSlug == "" {
+		return nil, errors.New("tenant context required")
+	}
+
+	if r.PGStore == nil {
+		return nil, errors.New("database not configured")
+	}
+
+	desc := ""
+	if input.Description != nil {
+		desc = *input.Description
+	}
+
+	actor := GetAuditActor(ctx)
+	now := time.Now()
+	role := &domain.Role{
+		ID:             uuid.New().String(),
+		Name:           input.Name,
+		Description:    desc,
+		IsDefault:      input.IsDefault != nil && *input.IsDefault,
+		IsSystem:       false,
+		Permissions:    input.Permissions,
+		CreatedBy:      actor.ID,
+		CreatedByEmail: actor.Email,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+
+	// Validate the policy up front so a bad one doesn't leave the role behind
+	var policy *domain.RolePolicy
+	if input.Policy != nil {
+		policy = convertInputToDomainPolicy(input.Policy, role.ID)
+		if err := normalizeNetworkPolicy(policy); err != nil {
+			return nil, err
+		}
+		if err := validateGenerationPolicy(policy); err != nil {
+			return nil, err
+		}
+		if err := validateOutputTransformPolicy(policy); err != nil {
+			return nil, err
+		}
+	}
+
+	// The role and its policy are created together or not at all
+	err := r.PGStore.InTx(ctx, func(tx *postgres.TenantStore) error {
+		if err := tx.CreateRole(ctx, role); err != nil {
+			return err
+		}
+		if policy != nil {
+			if err := tx.CreateRolePolicy(ctx, policy); err != nil {
+				return fmt.Errorf("create role policy: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		// Audit failure
+		r.AuditService.LogFailure(ctx, audit.LogEntry{
+			TenantSlug:   tenantSlug,
+			Action:       domain.AuditActionCreate,
+			ResourceType: domain.AuditResourceRole,
+			ResourceID:   role.ID,
+			ResourceName: role.Name,
+			Actor:        GetAuditActor(ctx),
+			IPAddress:    GetIPFromContext(ctx),
+			UserAgent:    GetUserAgentFromContext(ctx),
+		}, err.Error())
+		return nil, fmt.Errorf("failed to create role: %w", err)
+	}
+
+	// Audit success
+	r.AuditService.LogSuccess(ctx, audit.LogEntry{
+		TenantSlug:   tenantSlug,
+		Action:       domain.AuditActionCreate,
+		ResourceType: domain.AuditResourceRole,
+		ResourceID:   role.ID,
+		ResourceName: role.Name,
+		Actor:        GetAuditActor(ctx),
+		IPAddress:    GetIPFromContext(ctx),
+		UserAgent:    GetUserAgentFromContext(ctx),
+		NewValue: map[string]any{
+			"name":        role.Name,
+			"description": role.Description,
+			"is_default":  role.IsDefault,
+			"permissions": role.Permissions,
+		},
+	})
+
+	return &model.Role{
+		ID:          role.ID,
+		Name:        role.Name,
+		Description: &role.Description,
+		IsDefault:   role.IsDefault,
+		IsSystem:    role.IsSystem,
+		Permissions: role.Permissions,
+		CreatedAt:   role.CreatedAt,
+		UpdatedAt:   role.UpdatedAt,
+	}, nil
+}
+
+// UpdateRole is the resolver for the updateRole field.
+func (r *mutationResolver) UpdateRole(ctx context.Context, id string, input model.UpdateRoleInput) (*model.Role, error) {
+	tenantSlug := GetTenantFromContext(ctx)
+	if tenantSlug == "" {
+		return nil, errors.New("tenant context required")
+	}
+
+	if r.PGStore == nil {
+		return nil, errors.New("database not configured")
+	}
+
+	// Get existing role
+	existingRole, err := r.PGStore.GetRole(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role: %w", err)
+	}
+	if existingRole == nil {
+		return nil, errors.New("role not found")
+	}
+
+	// Update fields
+	if input.Name != nil {
+		existingRole.Name = *input.Name
+	}
+	if input.Description != nil {
+		existingRole.Description = *input.Description
+	}
+	if input.IsDefault != nil {
+		existingRole.IsDefault = *input.IsDefault
+	}
+	if input.Permissions != nil {
+		existingRole.Permissions = input.Permissions
+	}
+	existingRole.UpdatedAt = time.Now()
+
+	if err := r.PGStore.UpdateRole(ctx, existingRole); err != nil {
+		return nil, fmt.Errorf("failed to update role: %w", err)
+	}
+
+	return &model.Role{
+		ID:          existingRole.ID,
+		Name:        existingRole.Name,
+		Description: &existingRole.Description,
+		IsDefault:   existingRole.IsDefault,
+		IsSystem:    existingRole.IsSystem,
+		Permissions: existingRole.Permissions,
+		CreatedAt:   existingRole.CreatedAt,
+		UpdatedAt:   existingRole.UpdatedAt,
+	}, nil
+}
+
+// UpdateRolePolicy is the resolver for the updateRolePolicy field.
+func (r *mutationResolver) UpdateRolePolicy(ctx context.Context, roleID string, input model.RolePolicyInput) (*model.RolePolicy, error) {
+	tenantSlug := GetTenantFromContext(ctx)
+	if tenantSlug == "" {
+		return nil, errors.New("tenant context required")
+	}
+
+	if r.PGStore == nil {
+		return nil, errors.New("database not configured")
+	}
+
+	// Get existing policy for audit
+	existingPolicy, _ := r.PGStore.GetRolePolicy(ctx, roleID)
+	var oldValue map[string]any
+	if existingPolicy != nil {
+		oldValue = map[string]any{
 			"prompt_policies":    existingPolicy.PromptPolicies,
 			"model_restrictions": existingPolicy.ModelRestriction,
 			"rate_limits":        existingPolicy.RateLimitPolicy,
@@ -625,6 +1088,31 @@ func (r *mutationResolver) UpdateRolePolicy(ctx context.Context, roleID string,
 
 	// Convert GraphQL input to domain policy
 	policy := convertInputToDomainPolicy(&input, roleID)
+	// Keep the stored concurrency policy when the input omits it
+	if input.ConcurrencyPolicy == nil && existingPolicy != nil {
+		policy.ConcurrencyPolicy = existingPolicy.ConcurrencyPolicy
+	}
+	if input.NetworkPolicy == nil && existingPolicy != nil {
+		policy.NetworkPolicy = existingPolicy.NetworkPolicy
+	}
+	if input.FilePolicy == nil && existingPolicy != nil {
+		policy.FilePolicy = existingPolicy.FilePolicy
+	}
+	if input.GenerationPolicy == nil && existingPolicy != nil {
+		policy.GenerationPolicy = existingPolicy.GenerationPolicy
+	}
+	if input.OutputTransformPolicy == nil && existingPolicy != nil {
+		policy.OutputTransform = existingPolicy.OutputTransform
+	}
+	if err := normalizeNetworkPolicy(policy); err != nil {
+		return nil, err
+	}
+	if err := validateGenerationPolicy(policy); err != nil {
+		return nil, err
+	}
+	if err := validateOutputTransformPolicy(policy); err != nil {
+		return nil, err
+	}
 
 	// Save to database
 	if err := r.PGStore.UpdateRolePolicy(ctx, policy); err != nil {
@@ -668,6 +1156,58 @@ func (r *mutationResolver) UpdateRolePolicy(ctx context.Context, roleID string,
 	return convertDomainPolicyToModel(updatedPolicy), nil
 }
 
+// DryRunPolicy is the resolver for the dryRunPolicy field.
+func (r *mutationResolver) DryRunPolicy(ctx context.Context, input model.PolicyDryRunInput) (*model.PolicyDryRunResult, error) {
+	tenantSlug := GetTenantFromContext(ctx)
+	if tenantSlug == "" {
+		return nil, errors.New("tenant context required")
+	}
+	if r.PGStore == nil || r.Gateway == nil {
+		return nil, errors.New("database not configured")
+	}
+
+	req := convertPolicyDryRunInput(&input)
+	switch {
+	case input.APIKeyID != nil && *input.APIKeyID != "":
+		key, err := r.PGStore.GetAPIKey(ctx, *input.APIKeyID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load API key: %w", err)
+		}
+		if key == nil {
+			return nil, errors.New("API key not found")
+		}
+		req.APIKeyID = key.ID
+		req.RoleID = key.RoleID
+		req.GroupID = key.GroupID
+		req.ProjectID = key.ProjectID
+	case input.RoleID != nil && *input.RoleID != "":
+		req.RoleID = *input.RoleID
+	default:
+		return nil, errors.New("apiKeyId or roleId is required")
+	}
+
+	result, err := r.Gateway.DryRunPolicy(ctx, r.PGStore.TenantStore(), req)
+	if err != nil {
+		return nil, err
+	}
+	if req.ProjectID != "" && r.projects != nil {
+		check := policy.DryRunCheck{Policy: "project_budget", Outcome: policy.DryRunPass}
+		budget, err := r.projects.CheckBudget(ctx, req.ProjectID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check project budget: %w", err)
+		}
+		if !budget.Allowed {
+			check.Outcome = policy.DryRunBlock
+			check.Code = "project_budget_exceeded"
+			check.Message = budget.Reason
+		} else if budget.Warning != "" {
+			check.Message = budget.Warning
+		}
+		result.Add(check)
+	}
+	return convertPolicyDryRunResultToModel(result), nil
+}
+
 // DeleteRole is the resolver for the deleteRole field.
 func (r *mutationResolver) DeleteRole(ctx context.Context, id string) (bool, error) {
 	tenantSlug := GetTenantFromContext(ctx)
@@ -876,11 +1416,27 @@ func (r *mutationResolver) CreateAPIKey(ctx context.Context, input model.CreateA
 		expiresAt = input.ExpiresAt
 	}
 
-	// Create API key in tenant database
-	apiKey, fullKey, err := tenantStore.CreateAPIKey(ctx, input.Name, roleID, groupID, []string{}, expiresAt)
+	allowedCIDRs, err := domain.NormalizeCIDRs(input.AllowedCidrs)
 	if err != nil {
-		r.AuditService.LogFailure(ctx, audit.LogEntry{
-			TenantSlug:   tenantSlug,
+		return nil, fmt.Errorf("allowedCidrs: %w", err)
+	}
+
+	projectID := derefStr(input.ProjectID)
+	var project *domain.Project
+	if projectID != "" {
+		if r.projects == nil {
+			return nil, errors.New("projects not configured")
+		}
+		if project, err = r.projects.Get(ctx, projectID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Create API key in tenant database
+	apiKey, fullKey, err := tenantStore.CreateAPIKey(ctx, input.Name, roleID, groupID, []string{}, expiresAt)
+	if err != nil {
+		r.AuditService.LogFailure(ctx, audit.LogEntry{
+			TenantSlug:   tenantSlug,
 			Action:       domain.AuditActionCreate,
 			ResourceType: domain.AuditResourceAPIKey,
 			ResourceID:   "",
@@ -895,6 +1451,19 @@ func (r *mutationResolver) CreateAPIKey(ctx context.Context, input model.CreateA
 	// Update API key with creator info
 	tenantStore.UpdateAPIKeyCreator(ctx, apiKey.ID, actor.ID, actor.Email)
 
+	if len(allowedCIDRs) > 0 {
+		if err := tenantStore.UpdateAPIKeyAllowedCIDRs(ctx, apiKey.ID, allowedCIDRs); err != nil {
+			return nil, fmt.Errorf("failed to set API key allowlist: %w", err)
+		}
+	}
+	var projectName string
+	if project != nil {
+		if err := r.projects.AssignAPIKey(ctx, apiKey.ID, project.ID); err != nil {
+			return nil, fmt.Errorf("failed to assign API key to project: %w", err)
+		}
+		projectName = project.Name
+	}
+
 	// Load role/group info for response
 	var role *model.Role
 	var group *model.Group
@@ -933,11 +1502,13 @@ func (r *mutationResolver) CreateAPIKey(ctx context.Context, input model.CreateA
 		IPAddress:    GetIPFromContext(ctx),
 		UserAgent:    GetUserAgentFromContext(ctx),
 		NewValue: map[string]any{
-			"name":       apiKey.Name,
-			"key_prefix": apiKey.KeyPrefix,
-			"role":       roleName,
-			"group":      groupName,
-			"expires_at": expiresAt,
+			"name":          apiKey.Name,
+			"key_prefix":    apiKey.KeyPrefix,
+			"role":          roleName,
+			"group":         groupName,
+			"expires_at":    expiresAt,
+			"allowed_cidrs": allowedCIDRs,
+			"project":       projectName,
 		},
 	})
 
@@ -952,6 +1523,9 @@ func (r *mutationResolver) CreateAPIKey(ctx context.Context, input model.CreateA
 			CreatedBy:      &actor.ID,
 			CreatedByEmail: &actor.Email,
 			ExpiresAt:      expiresAt,
+			AllowedCidrs:   allowedCIDRs,
+			ProjectID:      optionalStr(projectID),
+			ProjectName:    optionalStr(projectName),
 		},
 		Secret: fullKey, // Only shown once!
 	}, nil
@@ -959,16 +1533,155 @@ func (r *mutationResolver) CreateAPIKey(ctx context.Context, input model.CreateA
 
 // UpdateAPIKey is the resolver for the updateAPIKey field.
 func (r *mutationResolver) UpdateAPIKey(ctx context.Context, id string, input model.UpdateAPIKeyInput) (*model.APIKey, error) {
-	name := ""
+	tenantSlug := GetTenantFromContext(ctx)
+	if tenantSlug == "" {
+		return nil, errors.New("tenant context required")
+	}
+
+	tenantStore, err := r.PGStore.GetTenantStore(tenantSlug)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant store: %w", err)
+	}
+
+	existing, err := tenantStore.GetAPIKey(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("getting API key: %w", err)
+	}
+	if existing == nil {
+		return nil, errors.New("API key not found")
+	}
+
+	// Role and group are exclusive; setting one clears the other
+	name, roleID, groupID := existing.Name, existing.RoleID, existing.GroupID
 	if input.Name != nil {
 		name = *input.Name
 	}
-	return &model.APIKey{
-		ID:        id,
-		Name:      name,
-		KeyPrefix: "mg_",
-		CreatedAt: time.Now(),
-	}, nil
+	if input.RoleID != nil && input.GroupID != nil && *input.RoleID != "" && *input.GroupID != "" {
+		return nil, errors.New("cannot assign both roleId and groupId to an API key")
+	}
+	if input.RoleID != nil && *input.RoleID != "" {
+		roleID, groupID = *input.RoleID, ""
+	} else if input.GroupID != nil && *input.GroupID != "" {
+		roleID, groupID = "", *input.GroupID
+	}
+
+	var allowedCIDRs []string
+	if input.AllowedCidrs != nil {
+		if allowedCIDRs, err = domain.NormalizeCIDRs(input.AllowedCidrs); err != nil {
+			return nil, fmt.Errorf("allowedCidrs: %w", err)
+		}
+	}
+	projectID := existing.ProjectID
+	if input.ProjectID != nil {
+		if r.projects == nil {
+			return nil, errors.New("projects not configured")
+		}
+		projectID = *input.ProjectID
+	}
+
+	actor := GetAuditActor(ctx)
+	logFailure := func(err error) {
+		r.AuditService.LogFailure(ctx, audit.LogEntry{
+			TenantSlug:   tenantSlug,
+			Action:       domain.AuditActionUpdate,
+			ResourceType: domain.AuditResourceAPIKey,
+			ResourceID:   id,
+			ResourceName: existing.Name,
+			Actor:        actor,
+			IPAddress:    GetIPFromContext(ctx),
+			UserAgent:    GetUserAgentFromContext(ctx),
+		}, err.Error())
+	}
+
+	if err := tenantStore.UpdateAPIKey(ctx, id, name, roleID, groupID); err != nil {
+		logFailure(err)
+		return nil, fmt.Errorf("failed to update API key: %w", err)
+	}
+	if input.AllowedCidrs != nil {
+		if err := tenantStore.UpdateAPIKeyAllowedCIDRs(ctx, id, allowedCIDRs); err != nil {
+			logFailure(err)
+			return nil, fmt.Errorf("failed to set API key allowlist: %w", err)
+		}
+	} else {
+		allowedCIDRs = existing.AllowedCIDRs
+	}
+	if projectID != existing.ProjectID {
+		if err := r.projects.AssignAPIKey(ctx, id, projectID); err != nil {
+			logFailure(err)
+			return nil, fmt.Errorf("failed to assign API key to project: %w", err)
+		}
+	}
+
+	r.AuditService.LogSuccess(ctx, audit.LogEntry{
+		TenantSlug:   tenantSlug,
+		Action:       domain.AuditActionUpdate,
+		ResourceType: domain.AuditResourceAPIKey,
+		ResourceID:   id,
+		ResourceName: name,
+		Actor:        actor,
+		IPAddress:    GetIPFromContext(ctx),
+		UserAgent:    GetUserAgentFromContext(ctx),
+		OldValue: map[string]any{
+			"name":          existing.Name,
+			"role_id":       existing.RoleID,
+			"group_id":      existing.GroupID,
+			"allowed_cidrs": existing.AllowedCIDRs,
+			"project_id":    existing.ProjectID,
+		},
+		NewValue: map[string]any{
+			"name":          name,
+			"role_id":       roleID,
+			"group_id":      groupID,
+			"allowed_cidrs": allowedCIDRs,
+			"project_id":    projectID,
+		},
+	})
+
+	return r.Query().APIKey(ctx, id)
+}
+
+// SetAPIKeyBudget is the resolver for the setAPIKeyBudget field.
+func (r *mutationResolver) SetAPIKeyBudget(ctx context.Context, id string, budget *model.APIKeyBudgetInput) (*model.APIKey, error) {
+	tenantSlug := GetTenantFromContext(ctx)
+	if tenantSlug == "" {
+		return nil, errors.New("tenant context required")
+	}
+	if r.keyBudgets == nil {
+		return nil, errors.New("API key budgets not configured")
+	}
+
+	tenantStore, err := r.PGStore.GetTenantStore(tenantSlug)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant store: %w", err)
+	}
+	existing, err := tenantStore.GetAPIKey(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("getting API key: %w", err)
+	}
+	if existing == nil {
+		return nil, errors.New("API key not found")
+	}
+
+	newBudget := convertAPIKeyBudgetInputToDomain(budget)
+	auditEntry := audit.LogEntry{
+		TenantSlug:   tenantSlug,
+		Action:       domain.AuditActionUpdate,
+		ResourceType: domain.AuditResourceAPIKey,
+		ResourceID:   id,
+		ResourceName: existing.Name,
+		Actor:        GetAuditActor(ctx),
+		IPAddress:    GetIPFromContext(ctx),
+		UserAgent:    GetUserAgentFromContext(ctx),
+		OldValue:     map[string]any{"budget": existing.Budget},
+		NewValue:     map[string]any{"budget": newBudget},
+	}
+	if err := r.keyBudgets.SetBudget(ctx, id, newBudget); err != nil {
+		r.AuditService.LogFailure(ctx, auditEntry, err.Error())
+		return nil, err
+	}
+	r.AuditService.LogSuccess(ctx, auditEntry)
+
+	return r.Query().APIKey(ctx, id)
 }
 
 // DeleteAPIKey is the resolver for the deleteAPIKey field.
@@ -1051,6 +1764,243 @@ func (r *mutationResolver) RevokeAPIKey(ctx context.Context, id string) (bool, e
 	return true, nil
 }
 
+// CreateMyAPIKey is the resolver for the createMyAPIKey field.
+func (r *mutationResolver) CreateMyAPIKey(ctx context.Context, input model.CreateMyAPIKeyInput) (*model.APIKeyWithSecret, error) {
+	tenantSlug := GetTenantFromContext(ctx)
+	if tenantSlug == "" {
+		return nil, errors.New("tenant context required")
+	}
+	userID := GetUserFromContext(ctx)
+	if userID == "" {
+		return nil, errors.New("user context required")
+	}
+	if strings.TrimSpace(input.Name) == "" {
+		return nil, errors.New("name is required")
+	}
+
+	tenantStore := r.PGStore.TenantStore()
+	user, err := tenantStore.GetUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("getting user: %w", err)
+	}
+	if user == nil || !user.IsActive {
+		return nil, errors.New("user not found")
+	}
+	// Personal keys use the owner's API role, which only an admin can assign
+	if user.APIRoleID == "" {
+		return nil, errors.New("no API role assigned to your account; ask an admin to assign one")
+	}
+
+	actor := GetAuditActor(ctx)
+	auditEntry := audit.LogEntry{
+		TenantSlug:   tenantSlug,
+		Action:       domain.AuditActionCreate,
+		ResourceType: domain.AuditResourceAPIKey,
+		ResourceName: input.Name,
+		Actor:        actor,
+		IPAddress:    GetIPFromContext(ctx),
+		UserAgent:    GetUserAgentFromContext(ctx),
+	}
+	apiKey, fullKey, err := tenantStore.CreateUserAPIKey(ctx, user.ID, user.Email, input.Name, input.ExpiresAt)
+	if err != nil {
+		r.AuditService.LogFailure(ctx, auditEntry, err.Error())
+		return nil, fmt.Errorf("failed to create API key: %w", err)
+	}
+
+	auditEntry.ResourceID = apiKey.ID
+	auditEntry.NewValue = map[string]any{
+		"name":       apiKey.Name,
+		"key_prefix": apiKey.KeyPrefix,
+		"owner":      user.Email,
+		"expires_at": apiKey.ExpiresAt,
+	}
+	r.AuditService.LogSuccess(ctx, auditEntry)
+
+	keyWithRole, err := tenantStore.GetAPIKey(ctx, apiKey.ID)
+	if err != nil || keyWithRole == nil {
+		return nil, fmt.Errorf("failed to load API key: %w", err)
+	}
+	result := convertAPIKeyWithRoleToModel(keyWithRole)
+	return &model.APIKeyWithSecret{
+		APIKey: &result,
+		Secret: fullKey, // Only shown once!
+	}, nil
+}
+
+// RevokeMyAPIKey is the resolver for the revokeMyAPIKey field.
+func (r *mutationResolver) RevokeMyAPIKey(ctx context.Context, id string) (bool, error) {
+	tenantSlug := GetTenantFromContext(ctx)
+	if tenantSlug == "" {
+		return false, errors.New("tenant context required")
+	}
+	userID := GetUserFromContext(ctx)
+	if userID == "" {
+		return false, errors.New("user context required")
+	}
+
+	tenantStore := r.PGStore.TenantStore()
+	key, err := tenantStore.GetAPIKey(ctx, id)
+	if err != nil {
+		return false, fmt.Errorf("getting API key: %w", err)
+	}
+	if key == nil || key.OwnerUserID != userID {
+		return false, errors.New("API key not found")
+	}
+	if err := tenantStore.RevokeAPIKey(ctx, id, "Revoked by owner"); err != nil {
+		return false, fmt.Errorf("failed to revoke API key: %w", err)
+	}
+
+	r.AuditService.LogSuccess(ctx, audit.LogEntry{
+		TenantSlug:   tenantSlug,
+		Action:       domain.AuditActionUpdate,
+		ResourceType: domain.AuditResourceAPIKey,
+		ResourceID:   id,
+		ResourceName: key.Name,
+		Actor:        GetAuditActor(ctx),
+		IPAddress:    GetIPFromContext(ctx),
+		UserAgent:    GetUserAgentFromContext(ctx),
+		NewValue:     map[string]any{"revoked": true},
+	})
+	return true, nil
+}
+
+// CreateProject is the resolver for the createProject field.
+func (r *mutationResolver) CreateProject(ctx context.Context, input model.CreateProjectInput) (*model.Project, error) {
+	tenantSlug := GetTenantFromContext(ctx)
+	if tenantSlug == "" {
+		return nil, errors.New("tenant context required")
+	}
+	if r.projects == nil {
+		return nil, errors.New("projects not configured")
+	}
+
+	actor := GetAuditActor(ctx)
+	project := &domain.Project{
+		Name:           input.Name,
+		Description:    derefStr(input.Description),
+		CreatedBy:      actor.ID,
+		CreatedByEmail: actor.Email,
+	}
+	if input.BudgetPolicy != nil {
+		project.BudgetPolicy = convertBudgetPolicyInput(input.BudgetPolicy)
+	}
+
+	auditEntry := audit.LogEntry{
+		TenantSlug:   tenantSlug,
+		Action:       domain.AuditActionCreate,
+		ResourceType: domain.AuditResourceProject,
+		ResourceName: input.Name,
+		Actor:        actor,
+		IPAddress:    GetIPFromContext(ctx),
+		UserAgent:    GetUserAgentFromContext(ctx),
+	}
+	if err := r.projects.Create(ctx, project); err != nil {
+		r.AuditService.LogFailure(ctx, auditEntry, err.Error())
+		return nil, err
+	}
+	auditEntry.ResourceID = project.ID
+	auditEntry.NewValue = map[string]any{
+		"name":          project.Name,
+		"description":   project.Description,
+		"budget_policy": project.BudgetPolicy,
+	}
+	r.AuditService.LogSuccess(ctx, auditEntry)
+
+	result := convertProjectToModel(project, nil)
+	return &result, nil
+}
+
+// UpdateProject is the resolver for the updateProject field.
+func (r *mutationResolver) UpdateProject(ctx context.Context, id string, input model.UpdateProjectInput) (*model.Project, error) {
+	tenantSlug := GetTenantFromContext(ctx)
+	if tenantSlug == "" {
+		return nil, errors.New("tenant context required")
+	}
+	if r.projects == nil {
+		return nil, errors.New("projects not configured")
+	}
+
+	existing, err := r.projects.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	project := *existing
+	if input.Name != nil {
+		project.Name = *input.Name
+	}
+	if input.Description != nil {
+		project.Description = *input.Description
+	}
+	if input.BudgetPolicy != nil {
+		project.BudgetPolicy = convertBudgetPolicyInput(input.BudgetPolicy)
+	}
+
+	auditEntry := audit.LogEntry{
+		TenantSlug:   tenantSlug,
+		Action:       domain.AuditActionUpdate,
+		ResourceType: domain.AuditResourceProject,
+		ResourceID:   id,
+		ResourceName: existing.Name,
+		Actor:        GetAuditActor(ctx),
+		IPAddress:    GetIPFromContext(ctx),
+		UserAgent:    GetUserAgentFromContext(ctx),
+		OldValue: map[string]any{
+			"name":          existing.Name,
+			"description":   existing.Description,
+			"budget_policy": existing.BudgetPolicy,
+		},
+	}
+	if err := r.projects.Update(ctx, &project); err != nil {
+		r.AuditService.LogFailure(ctx, auditEntry, err.Error())
+		return nil, err
+	}
+	auditEntry.ResourceName = project.Name
+	auditEntry.NewValue = map[string]any{
+		"name":          project.Name,
+		"description":   project.Description,
+		"budget_policy": project.BudgetPolicy,
+	}
+	r.AuditService.LogSuccess(ctx, auditEntry)
+
+	return r.Query().Project(ctx, id)
+}
+
+// DeleteProject is the resolver for the deleteProject field.
+func (r *mutationResolver) DeleteProject(ctx context.Context, id string) (bool, error) {
+	tenantSlug := GetTenantFromContext(ctx)
+	if tenantSlug == "" {
+		return false, errors.New("tenant context required")
+	}
+	if r.projects == nil {
+		return false, errors.New("projects not configured")
+	}
+
+	existing, err := r.projects.Get(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	auditEntry := audit.LogEntry{
+		TenantSlug:   tenantSlug,
+		Action:       domain.AuditActionDelete,
+		ResourceType: domain.AuditResourceProject,
+		ResourceID:   id,
+		ResourceName: existing.Name,
+		Actor:        GetAuditActor(ctx),
+		IPAddress:    GetIPFromContext(ctx),
+		UserAgent:    GetUserAgentFromContext(ctx),
+		OldValue: map[string]any{
+			"name":          existing.Name,
+			"api_key_count": existing.APIKeyCount,
+		},
+	}
+	if err := r.projects.Delete(ctx, id); err != nil {
+		r.AuditService.LogFailure(ctx, auditEntry, err.Error())
+		return false, err
+	}
+	r.AuditService.LogSuccess(ctx, auditEntry)
+	return true, nil
+}
+
 // CreateUser is the resolver for the createUser field.
 func (r *mutationResolver) CreateUser(ctx context.Context, email string, name string, password string, role string) (*model.User, error) {
 	tenantSlug := GetTenantFromContext(ctx)
@@ -1102,7 +2052,7 @@ func (r *mutationResolver) CreateUser(ctx context.Context, email string, name st
 }
 
 // UpdateUser is the resolver for the updateUser field.
-func (r *mutationResolver) UpdateUser(ctx context.Context, id string, name *string, role *string) (*model.User, error) {
+func (r *mutationResolver) UpdateUser(ctx context.Context, id string, name *string, role *string, isActive *bool, apiRoleID *string, dashboardRoleID *string) (*model.User, error) {
 	tenantSlug := GetTenantFromContext(ctx)
 	if tenantSlug == "" {
 		return nil, fmt.Errorf("tenant not specified")
@@ -1120,12 +2070,29 @@ func (r *mutationResolver) UpdateUser(ctx context.Context, id string, name *stri
 	var oldValue map[string]any
 	if existingUser != nil {
 		oldValue = map[string]any{
-			"name": existingUser.Name,
-			"role": existingUser.Role,
+			"name":        existingUser.Name,
+			"role":        existingUser.Role,
+			"is_active":   existingUser.IsActive,
+			"api_role_id": existingUser.APIRoleID,
+
+			"dashboard_role_id": existingUser.DashboardRoleID,
 		}
 	}
 
-	user, err := tenantStore.UpdateUser(ctx, id, name, role, nil)
+	for _, roleID := range []*string{apiRoleID, dashboardRoleID} {
+		if roleID == nil || *roleID == "" {
+			continue
+		}
+		found, err := r.PGStore.GetRole(ctx, *roleID)
+		if err != nil {
+			return nil, fmt.Errorf("getting role: %w", err)
+		}
+		if found == nil {
+			return nil, errors.New("role not found")
+		}
+	}
+
+	user, err := tenantStore.UpdateUser(ctx, id, name, role, isActive, apiRoleID, dashboardRoleID)
 	if err != nil {
 		r.AuditService.LogFailure(ctx, audit.LogEntry{
 			TenantSlug:   tenantSlug,
@@ -1155,8 +2122,12 @@ func (r *mutationResolver) UpdateUser(ctx context.Context, id string, name *stri
 		UserAgent:    GetUserAgentFromContext(ctx),
 		OldValue:     oldValue,
 		NewValue: map[string]any{
-			"name": user.Name,
-			"role": user.Role,
+			"name":        user.Name,
+			"role":        user.Role,
+			"is_active":   user.IsActive,
+			"api_role_id": user.APIRoleID,
+
+			"dashboard_role_id": user.DashboardRoleID,
 		},
 	})
 
@@ -1210,58 +2181,1146 @@ func (r *mutationResolver) DeleteUser(ctx context.Context, id string) (bool, err
 		Actor:        actor,
 		IPAddress:    GetIPFromContext(ctx),
 		UserAgent:    GetUserAgentFromContext(ctx),
-		OldValue: map[string]any{
-			"email": userName,
-		},
-	})
+		OldValue: map[string]any{
+			"email": userName,
+		},
+	})
+
+	return true, nil
+}
+
+// ForcePasswordReset is the resolver for the forcePasswordReset field.
+func (r *mutationResolver) ForcePasswordReset(ctx context.Context, id string, temporaryPassword string) (*model.User, error) {
+	tenantSlug := GetTenantFromContext(ctx)
+	tenantStore, err := r.PGStore.GetTenantStore(tenantSlug)
+	if err != nil {
+		return nil, fmt.Errorf("getting tenant store: %w", err)
+	}
+
+	actor := GetAuditActor(ctx)
+	entry := audit.LogEntry{
+		TenantSlug:   tenantSlug,
+		Action:       domain.AuditActionUpdate,
+		ResourceType: domain.AuditResourceUser,
+		ResourceID:   id,
+		Actor:        actor,
+		IPAddress:    GetIPFromContext(ctx),
+		UserAgent:    GetUserAgentFromContext(ctx),
+		NewValue:     map[string]any{"password_reset": true, "must_change_password": true},
+	}
+
+	user, err := tenantStore.SetTemporaryPassword(ctx, id, temporaryPassword)
+	if err != nil {
+		r.AuditService.LogFailure(ctx, entry, err.Error())
+		return nil, fmt.Errorf("resetting password: %w", err)
+	}
+	entry.ResourceName = user.Email
+	r.AuditService.LogSuccess(ctx, entry)
+
+	result := convertTenantUserToModel(user)
+	return &result, nil
+}
+
+// UnlockUser is the resolver for the unlockUser field.
+func (r *mutationResolver) UnlockUser(ctx context.Context, id string) (bool, error) {
+	tenantSlug := GetTenantFromContext(ctx)
+	tenantStore, err := r.PGStore.GetTenantStore(tenantSlug)
+	if err != nil {
+		return false, fmt.Errorf("getting tenant store: %w", err)
+	}
+
+	unlocked, err := tenantStore.UnlockUser(ctx, id)
+	if err != nil {
+		return false, fmt.Errorf("unlocking user: %w", err)
+	}
+	if !unlocked {
+		return false, errors.New("user not found")
+	}
+
+	r.AuditService.LogSuccess(ctx, audit.LogEntry{
+		TenantSlug:   tenantSlug,
+		Action:       domain.AuditActionEnable,
+		ResourceType: domain.AuditResourceUser,
+		ResourceID:   id,
+		Actor:        GetAuditActor(ctx),
+		IPAddress:    GetIPFromContext(ctx),
+		UserAgent:    GetUserAgentFromContext(ctx),
+		NewValue:     map[string]any{"unlocked": true},
+	})
+	return true, nil
+}
+
+// ResetTwoFactor is the resolver for the resetTwoFactor field.
+func (r *mutationResolver) ResetTwoFactor(ctx context.Context, id string) (bool, error) {
+	if r.twoFactor == nil {
+		return false, errors.New("two-factor authentication not configured")
+	}
+	user, err := r.PGStore.TenantStore().GetUser(ctx, id)
+	if err != nil {
+		return false, fmt.Errorf("getting user: %w", err)
+	}
+	if user == nil {
+		return false, errors.New("user not found")
+	}
+
+	reset, err := r.twoFactor.Reset(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	if !reset {
+		return false, twofactor.ErrNotEnabled
+	}
+	r.AuditService.LogSuccess(ctx, audit.LogEntry{
+		TenantSlug:   GetTenantFromContext(ctx),
+		Action:       domain.AuditActionDelete,
+		ResourceType: domain.AuditResourceTwoFactor,
+		ResourceID:   user.ID,
+		ResourceName: user.Email,
+		Actor:        GetAuditActor(ctx),
+		IPAddress:    GetIPFromContext(ctx),
+		UserAgent:    GetUserAgentFromContext(ctx),
+	})
+	return true, nil
+}
+
+// SetTwoFactorSettings is the resolver for the setTwoFactorSettings field.
+func (r *mutationResolver) SetTwoFactorSettings(ctx context.Context, input model.TwoFactorSettingsInput) (*model.TwoFactorSettings, error) {
+	if r.twoFactor == nil {
+		return nil, errors.New("two-factor authentication not configured")
+	}
+
+	old, err := r.twoFactor.Settings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	auditEntry := audit.LogEntry{
+		TenantSlug:   GetTenantFromContext(ctx),
+		Action:       domain.AuditActionUpdate,
+		ResourceType: domain.AuditResourceTwoFactor,
+		ResourceName: "two_factor_settings",
+		Actor:        GetAuditActor(ctx),
+		IPAddress:    GetIPFromContext(ctx),
+		UserAgent:    GetUserAgentFromContext(ctx),
+		OldValue:     map[string]any{"require_for_admins": old.RequireForAdmins, "require_for_all": old.RequireForAll},
+		NewValue:     map[string]any{"require_for_admins": input.RequireForAdmins, "require_for_all": input.RequireForAll},
+	}
+	saved, err := r.twoFactor.UpdateSettings(ctx, domain.TwoFactorSettings{
+		RequireForAdmins: input.RequireForAdmins,
+		RequireForAll:    input.RequireForAll,
+	}, GetAuditActor(ctx).Email)
+	if err != nil {
+		r.AuditService.LogFailure(ctx, auditEntry, err.Error())
+		return nil, err
+	}
+	r.AuditService.LogSuccess(ctx, auditEntry)
+
+	result := convertTwoFactorSettingsToModel(*saved)
+	return &result, nil
+}
+
+// BeginTwoFactorEnrollment is the resolver for the beginTwoFactorEnrollment field.
+func (r *mutationResolver) BeginTwoFactorEnrollment(ctx context.Context, input model.TwoFactorCredentialsInput) (*model.TwoFactorEnrollment, error) {
+	user, err := r.twoFactorUser(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	enrollment, err := r.twoFactor.BeginEnrollment(ctx, user.ID, user.Email)
+	if err != nil {
+		return nil, err
+	}
+	return &model.TwoFactorEnrollment{
+		Secret:          enrollment.Secret,
+		ProvisioningURI: enrollment.ProvisioningURI,
+		QRCode:          enrollment.QRCodeDataURL,
+	}, nil
+}
+
+// ConfirmTwoFactorEnrollment is the resolver for the confirmTwoFactorEnrollment field.
+func (r *mutationResolver) ConfirmTwoFactorEnrollment(ctx context.Context, input model.TwoFactorCredentialsInput) ([]string, error) {
+	user, err := r.twoFactorUser(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	codes, err := r.twoFactor.ConfirmEnrollment(ctx, user.ID, derefStr(input.Code))
+	if err != nil {
+		if errors.Is(err, twofactor.ErrInvalidCode) {
+			r.auditTwoFactor(ctx, user, domain.AuditActionEnable, err)
+		}
+		return nil, err
+	}
+	r.auditTwoFactor(ctx, user, domain.AuditActionEnable, nil)
+	return codes, nil
+}
+
+// DisableTwoFactor is the resolver for the disableTwoFactor field.
+func (r *mutationResolver) DisableTwoFactor(ctx context.Context, input model.TwoFactorCredentialsInput) (bool, error) {
+	user, err := r.twoFactorUser(ctx, input)
+	if err != nil {
+		return false, err
+	}
+	if err := r.twoFactor.Disable(ctx, user.ID, user.Role, derefStr(input.Code)); err != nil {
+		var locked *passwords.LockedError
+		if errors.Is(err, twofactor.ErrInvalidCode) || errors.As(err, &locked) {
+			r.auditTwoFactor(ctx, user, domain.AuditActionDisable, err)
+		}
+		return false, err
+	}
+	r.auditTwoFactor(ctx, user, domain.AuditActionDisable, nil)
+	return true, nil
+}
+
+// RegenerateTwoFactorBackupCodes is the resolver for the regenerateTwoFactorBackupCodes field.
+func (r *mutationResolver) RegenerateTwoFactorBackupCodes(ctx context.Context, input model.TwoFactorCredentialsInput) ([]string, error) {
+	user, err := r.twoFactorUser(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	codes, err := r.twoFactor.RegenerateBackupCodes(ctx, user.ID, derefStr(input.Code))
+	if err != nil {
+		var locked *passwords.LockedError
+		if errors.Is(err, twofactor.ErrInvalidCode) || errors.As(err, &locked) {
+			r.auditTwoFactor(ctx, user, domain.AuditActionUpdate, err)
+		}
+		return nil, err
+	}
+	r.auditTwoFactor(ctx, user, domain.AuditActionUpdate, nil)
+	return codes, nil
+}
+
+// UpdateDigestSubscription is the resolver for the updateDigestSubscription field.
+func (r *mutationResolver) UpdateDigestSubscription(ctx context.Context, input model.UpdateDigestSubscriptionInput) (*model.DigestSubscription, error) {
+	userID := GetUserFromContext(ctx)
+	if userID == "" {
+		return nil, errors.New("user context required")
+	}
+	if r.digests == nil {
+		return nil, errors.New("usage digests not configured")
+	}
+
+	sub, err := r.digests.Subscribe(ctx, userID,
+		domain.DigestFrequency(strings.ToLower(string(input.Frequency))), derefStr(input.RoleID))
+	if err != nil {
+		return nil, err
+	}
+	result := convertDigestSubscriptionToModel(sub, r.digests.Enabled())
+	return &result, nil
+}
+
+// SendUsageDigest is the resolver for the sendUsageDigest field.
+func (r *mutationResolver) SendUsageDigest(ctx context.Context) (bool, error) {
+	userID := GetUserFromContext(ctx)
+	if userID == "" {
+		return false, errors.New("user context required")
+	}
+	if r.digests == nil {
+		return false, errors.New("usage digests not configured")
+	}
+	if err := r.digests.Send(ctx, userID); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// CreateBudgetAlert is the resolver for the createBudgetAlert field.
+func (r *mutationResolver) CreateBudgetAlert(ctx context.Context, input model.CreateBudgetAlertInput) (*model.BudgetAlert, error) {
+	enabled := true
+	if input.Enabled != nil {
+		enabled = *input.Enabled
+	}
+	return &model.BudgetAlert{
+		ID:            uuid.New().String(),
+		Name:          input.Name,
+		Type:          input.Type,
+		Threshold:     input.Threshold,
+		ThresholdType: input.ThresholdType,
+		Period:        input.Period,
+		Enabled:       enabled,
+		CreatedAt:     time.Now(),
+	}, nil
+}
+
+// UpdateBudgetAlert is the resolver for the updateBudgetAlert field.
+func (r *mutationResolver) UpdateBudgetAlert(ctx context.Context, id string, input model.UpdateBudgetAlertInput) (*model.BudgetAlert, error) {
+	name := ""
+	if input.Name != nil {
+		name = *input.Name
+	}
+	threshold := 0.0
+	if input.Threshold != nil {
+		threshold = *input.Threshold
+	}
+	enabled := true
+	if input.Enabled != nil {
+		enabled = *input.Enabled
+	}
+	return &model.BudgetAlert{
+		ID:        id,
+		Name:      name,
+		Threshold: threshold,
+		Enabled:   enabled,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// DeleteBudgetAlert is the resolver for the deleteBudgetAlert field.
+func (r *mutationResolver) DeleteBudgetAlert(ctx context.Context, id string) (bool, error) {
+	return true, nil
+}
+
+// SetQuotaLimits is the resolver for the setQuotaLimits field.
+func (r *mutationResolver) SetQuotaLimits(ctx context.Context, input model.SetQuotaLimitsInput) (*model.QuotaPeriod, error) {
+	tenantSlug := GetTenantFromContext(ctx)
+	if tenantSlug == "" {
+		return nil, errors.New("tenant context required")
+	}
+	if r.quota == nil {
+		return nil, errors.New("quotas not configured")
+	}
+
+	limits := domain.QuotaLimits{
+		RequestsLimit: int64(input.RequestsLimit),
+		TokensLimit:   int64(input.TokensLimit),
+		CostLimitUSD:  input.CostLimitUsd,
+		UpdatedBy:     GetUserEmailFromContext(ctx),
+	}
+	auditEntry := audit.LogEntry{
+		TenantSlug:   tenantSlug,
+		Action:       domain.AuditActionUpdate,
+		ResourceType: domain.AuditResourceTenant,
+		ResourceID:   tenantSlug,
+		Actor:        GetAuditActor(ctx),
+		IPAddress:    GetIPFromContext(ctx),
+		UserAgent:    GetUserAgentFromContext(ctx),
+		Details: map[string]any{
+			"operation":      "set_quota_limits",
+			"requests_limit": limits.RequestsLimit,
+			"tokens_limit":   limits.TokensLimit,
+			"cost_limit_usd": limits.CostLimitUSD,
+		},
+	}
+	if _, err := r.quota.SetLimits(ctx, limits); err != nil {
+		r.AuditService.LogFailure(ctx, auditEntry, err.Error())
+		return nil, err
+	}
+	r.AuditService.LogSuccess(ctx, auditEntry)
+
+	period, err := r.quota.Current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := convertQuotaPeriodToModel(period)
+	return &result, nil
+}
+
+// UpdateRetentionPolicy is the resolver for the updateRetentionPolicy field.
+func (r *mutationResolver) UpdateRetentionPolicy(ctx context.Context, input model.UpdateRetentionPolicyInput) (*model.RetentionPolicy, error) {
+	tenantSlug := GetTenantFromContext(ctx)
+	if tenantSlug == "" {
+		return nil, errors.New("tenant context required")
+	}
+	if !domain.IsRetentionTable(input.Table) {
+		return nil, fmt.Errorf("table %q does not support retention", input.Table)
+	}
+	if input.RetentionDays != nil && *input.RetentionDays < 0 {
+		return nil, errors.New("retentionDays must not be negative")
+	}
+
+	tenantStore, err := r.PGStore.GetTenantStore(tenantSlug)
+	if err != nil {
+		return nil, fmt.Errorf("getting tenant store: %w", err)
+	}
+	overrides, err := tenantStore.ListRetentionOverrides(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load retention policies: %w", err)
+	}
+
+	override := overrides[input.Table]
+	if override == nil {
+		override = &domain.RetentionOverride{Table: input.Table}
+		overrides[input.Table] = override
+	}
+	old := retentionPolicyFor(r.Config.Retention, overrides, input.Table)
+	if input.RetentionDays != nil {
+		override.RetentionDays = input.RetentionDays
+	}
+	if input.ArchiveBeforeDelete != nil {
+		override.ArchiveBeforeDelete = input.ArchiveBeforeDelete
+	}
+	if input.Enabled != nil {
+		override.Enabled = input.Enabled
+	}
+
+	auditEntry := audit.LogEntry{
+		TenantSlug:   tenantSlug,
+		Action:       domain.AuditActionUpdate,
+		ResourceType: domain.AuditResourceRetentionPolicy,
+		ResourceID:   input.Table,
+		ResourceName: input.Table,
+		Actor:        GetAuditActor(ctx),
+		IPAddress:    GetIPFromContext(ctx),
+		UserAgent:    GetUserAgentFromContext(ctx),
+	}
+	if err := tenantStore.SaveRetentionOverride(ctx, override); err != nil {
+		r.AuditService.LogFailure(ctx, auditEntry, err.Error())
+		return nil, fmt.Errorf("failed to update retention policy: %w", err)
+	}
+
+	policy := retentionPolicyFor(r.Config.Retention, overrides, input.Table)
+	auditEntry.OldValue = retentionPolicyAuditValue(old)
+	auditEntry.NewValue = retentionPolicyAuditValue(policy)
+	r.AuditService.LogSuccess(ctx, auditEntry)
+
+	return convertRetentionPolicyToModel(policy), nil
+}
+
+// SetFeatureFlag is the resolver for the setFeatureFlag field.
+func (r *mutationResolver) SetFeatureFlag(ctx context.Context, input model.SetFeatureFlagInput) (*model.FeatureFlag, error) {
+	tenantSlug := GetTenantFromContext(ctx)
+	if tenantSlug == "" {
+		return nil, errors.New("tenant context required")
+	}
+	if r.featureFlags == nil {
+		return nil, errors.New("feature flags not configured")
+	}
+
+	tenantStore, err := r.PGStore.GetTenantStore(tenantSlug)
+	if err != nil {
+		return nil, fmt.Errorf("getting tenant store: %w", err)
+	}
+	roleID := derefStr(input.RoleID)
+	if roleID != "" {
+		if role, err := tenantStore.GetRole(ctx, roleID); err != nil || role == nil {
+			return nil, fmt.Errorf("role %q not found", roleID)
+		}
+	}
+
+	actor := GetAuditActor(ctx)
+	auditEntry := audit.LogEntry{
+		TenantSlug:   tenantSlug,
+		Action:       domain.AuditActionUpdate,
+		ResourceType: domain.AuditResourceFeatureFlag,
+		ResourceID:   input.Key,
+		ResourceName: input.Key,
+		Actor:        actor,
+		IPAddress:    GetIPFromContext(ctx),
+		UserAgent:    GetUserAgentFromContext(ctx),
+	}
+	flag, change, err := r.featureFlags.Set(ctx, featureflags.Update{
+		Key:        input.Key,
+		RoleID:     roleID,
+		Enabled:    input.Enabled,
+		Note:       derefStr(input.Note),
+		ActorID:    actor.ID,
+		ActorEmail: actor.Email,
+	})
+	if err != nil {
+		r.AuditService.LogFailure(ctx, auditEntry, err.Error())
+		return nil, err
+	}
+
+	auditEntry.OldValue = featureFlagChangeAuditValue(change.RoleID, change.PreviousEnabled)
+	auditEntry.NewValue = featureFlagChangeAuditValue(change.RoleID, change.Enabled)
+	if change.Note != "" {
+		auditEntry.Details = map[string]any{"note": change.Note}
+	}
+	r.AuditService.LogSuccess(ctx, auditEntry)
+
+	return convertFeatureFlagToModel(*flag, roleNamesByID(ctx, tenantStore)), nil
+}
+
+// DisableTraffic is the resolver for the disableTraffic field.
+func (r *mutationResolver) DisableTraffic(ctx context.Context, input model.DisableTrafficInput) (*model.KillSwitch, error) {
+	if r.killSwitch == nil {
+		return nil, errors.New("kill switches not configured")
+	}
+	if strings.TrimSpace(input.Reason) == "" {
+		return nil, errors.New("reason is required")
+	}
+
+	ks := domain.KillSwitch{
+		Provider:  domain.Provider(strings.ToLower(string(input.Provider))),
+		Model:     derefStr(input.Model),
+		Reason:    input.Reason,
+		ExpiresAt: input.ExpiresAt,
+	}
+	if input.ExpiresInMinutes != nil {
+		if *input.ExpiresInMinutes <= 0 {
+			return nil, errors.New("expiresInMinutes must be positive")
+		}
+		expiresAt := time.Now().Add(time.Duration(*input.ExpiresInMinutes) * time.Minute)
+		ks.ExpiresAt = &expiresAt
+	}
+	saved, err := r.killSwitch.Disable(ctx, ks, killSwitchActor(ctx))
+	if err != nil {
+		return nil, err
+	}
+	result := convertKillSwitchToModel(saved)
+	return &result, nil
+}
+
+// EnableTraffic is the resolver for the enableTraffic field.
+func (r *mutationResolver) EnableTraffic(ctx context.Context, provider model.Provider, model *string) (bool, error) {
+	if r.killSwitch == nil {
+		return false, errors.New("kill switches not configured")
+	}
+	return r.killSwitch.Enable(ctx, domain.Provider(strings.ToLower(string(provider))), derefStr(model), killSwitchActor(ctx))
+}
+
+// CreateExperiment is the resolver for the createExperiment field.
+func (r *mutationResolver) CreateExperiment(ctx context.Context, input model.ExperimentInput) (*model.Experiment, error) {
+	if r.experiments == nil {
+		return nil, errors.New("experiments not configured")
+	}
+
+	e, err := r.experiments.Create(ctx, convertExperimentInput(input), experimentActor(ctx))
+	if err != nil {
+		return nil, err
+	}
+	result := convertExperimentToModel(e)
+	return &result, nil
+}
+
+// UpdateExperiment is the resolver for the updateExperiment field.
+func (r *mutationResolver) UpdateExperiment(ctx context.Context, id string, input model.ExperimentInput) (*model.Experiment, error) {
+	if r.experiments == nil {
+		return nil, errors.New("experiments not configured")
+	}
+
+	e := convertExperimentInput(input)
+	e.ID = id
+	updated, err := r.experiments.Update(ctx, e, experimentActor(ctx))
+	if err != nil {
+		return nil, err
+	}
+	result := convertExperimentToModel(updated)
+	return &result, nil
+}
+
+// SetExperimentStatus is the resolver for the setExperimentStatus field.
+func (r *mutationResolver) SetExperimentStatus(ctx context.Context, id string, status model.ExperimentStatus) (*model.Experiment, error) {
+	if r.experiments == nil {
+		return nil, errors.New("experiments not configured")
+	}
+
+	e, err := r.experiments.SetStatus(ctx, id, domain.ExperimentStatus(strings.ToLower(string(status))), experimentActor(ctx))
+	if err != nil {
+		return nil, err
+	}
+	result := convertExperimentToModel(e)
+	return &result, nil
+}
+
+// DeleteExperiment is the resolver for the deleteExperiment field.
+func (r *mutationResolver) DeleteExperiment(ctx context.Context, id string) (bool, error) {
+	if r.experiments == nil {
+		return false, errors.New("experiments not configured")
+	}
+	return r.experiments.Delete(ctx, id, experimentActor(ctx))
+}
+
+// SubmitFeedback is the resolver for the submitFeedback field.
+func (r *mutationResolver) SubmitFeedback(ctx context.Context, input model.FeedbackInput) (*model.Feedback, error) {
+	if r.feedback == nil {
+		return nil, errors.New("feedback not configured")
+	}
+
+	f := &domain.Feedback{
+		RequestID:   input.ResponseID,
+		Rating:      input.Rating,
+		Comment:     derefStr(input.Comment),
+		SubmittedBy: GetAuditActor(ctx).Email,
+	}
+	if input.Thumbs != nil {
+		up := *input.Thumbs == model.ThumbsUp
+		f.ThumbsUp = &up
+	}
+	if err := r.feedback.Submit(ctx, f, ""); err != nil {
+		return nil, err
+	}
+	result := convertFeedbackToModel(f)
+	return &result, nil
+}
+
+// SetEmbedder is the resolver for the setEmbedder field.
+func (r *mutationResolver) SetEmbedder(ctx context.Context, input model.EmbedderInput) (*model.EmbedderSettings, error) {
+	if r.embedders == nil {
+		return nil, errors.New("embedder settings not configured")
+	}
+
+	saved, err := r.embedders.Set(ctx, domain.EmbedderSettings{
+		Feature:    embedderFeatureFromModel(input.Feature),
+		Type:       strings.ToLower(strings.TrimSpace(input.Type)),
+		Model:      strings.TrimSpace(input.Model),
+		BaseURL:    strings.TrimSpace(derefStr(input.BaseURL)),
+		APIKey:     derefStr(input.APIKey),
+		Dimensions: derefInt(input.Dimensions),
+	}, embedderActor(ctx))
+	if err != nil {
+		return nil, err
+	}
+	result := convertEmbedderSettingsToModel(*saved)
+	return &result, nil
+}
+
+// ClearEmbedder is the resolver for the clearEmbedder field.
+func (r *mutationResolver) ClearEmbedder(ctx context.Context, feature model.EmbedderFeature) (*model.EmbedderSettings, error) {
+	if r.embedders == nil {
+		return nil, errors.New("embedder settings not configured")
+	}
+
+	settings, err := r.embedders.Clear(ctx, embedderFeatureFromModel(feature), embedderActor(ctx))
+	if err != nil {
+		return nil, err
+	}
+	result := convertEmbedderSettingsToModel(*settings)
+	return &result, nil
+}
+
+// SetDataPlaneAuditSettings is the resolver for the setDataPlaneAuditSettings field.
+func (r *mutationResolver) SetDataPlaneAuditSettings(ctx context.Context, input model.DataPlaneAuditSettingsInput) (*model.DataPlaneAuditSettings, error) {
+	if r.dataAudit == nil {
+		return nil, errors.New("data-plane audit not configured")
+	}
+
+	old := r.dataAudit.Settings()
+	auditEntry := audit.LogEntry{
+		TenantSlug:   GetTenantFromContext(ctx),
+		Action:       domain.AuditActionUpdate,
+		ResourceType: domain.AuditResourceDataPlaneAudit,
+		ResourceName: "data_plane_audit",
+		Actor:        GetAuditActor(ctx),
+		IPAddress:    GetIPFromContext(ctx),
+		UserAgent:    GetUserAgentFromContext(ctx),
+		OldValue:     map[string]any{"enabled": old.Enabled, "store_events": old.StoreEvents, "webhook_url": old.WebhookURL},
+		NewValue:     map[string]any{"enabled": input.Enabled, "store_events": input.StoreEvents, "webhook_url": derefStr(input.WebhookURL)},
+	}
+	saved, err := r.dataAudit.Update(ctx, domain.DataPlaneAuditSettings{
+		Enabled:     input.Enabled,
+		StoreEvents: input.StoreEvents,
+		WebhookURL:  strings.TrimSpace(derefStr(input.WebhookURL)),
+	}, GetAuditActor(ctx).Email)
+	if err != nil {
+		r.AuditService.LogFailure(ctx, auditEntry, err.Error())
+		return nil, err
+	}
+	r.AuditService.LogSuccess(ctx, auditEntry)
+
+	result := convertDataPlaneAuditSettingsToModel(*saved, r.dataAudit.Dropped())
+	return &result, nil
+}
+
+// RotateAttestationKey is the resolver for the rotateAttestationKey field.
+func (r *mutationResolver) RotateAttestationKey(ctx context.Context) (*model.AttestationKey, error) {
+	if r.attestation == nil {
+		return nil, errors.New("response attestations not configured")
+	}
+
+	auditEntry := audit.LogEntry{
+		TenantSlug:   GetTenantFromContext(ctx),
+		Action:       domain.AuditActionCreate,
+		ResourceType: domain.AuditResourceAttestationKey,
+		Actor:        GetAuditActor(ctx),
+		IPAddress:    GetIPFromContext(ctx),
+		UserAgent:    GetUserAgentFromContext(ctx),
+	}
+	key, err := r.attestation.Rotate(ctx, GetAuditActor(ctx).Email)
+	if err != nil {
+		r.AuditService.LogFailure(ctx, auditEntry, err.Error())
+		return nil, err
+	}
+	auditEntry.ResourceID = key.ID
+	auditEntry.ResourceName = key.ID
+	r.AuditService.LogSuccess(ctx, auditEntry)
+
+	result := convertAttestationKeyToModel(*key)
+	return &result, nil
+}
+
+// SetModelDeprecation is the resolver for the setModelDeprecation field.
+func (r *mutationResolver) SetModelDeprecation(ctx context.Context, input model.SetModelDeprecationInput) (*model.ModelDeprecation, error) {
+	if r.deprecations == nil {
+		return nil, errors.New("model deprecations not configured")
+	}
+
+	d := &domain.ModelDeprecation{
+		Provider:         domain.Provider(strings.ToLower(string(input.Provider))),
+		ModelID:          input.ModelID,
+		SunsetAt:         input.SunsetAt,
+		ReplacementModel: strings.TrimSpace(derefStr(input.ReplacementModel)),
+		Notice:           derefStr(input.Notice),
+	}
+	auditEntry := audit.LogEntry{
+		TenantSlug:   GetTenantFromContext(ctx),
+		Action:       domain.AuditActionUpdate,
+		ResourceType: domain.AuditResourceModelDeprecation,
+		ResourceID:   string(d.Provider) + "/" + d.ModelID,
+		ResourceName: d.ModelID,
+		Actor:        GetAuditActor(ctx),
+		IPAddress:    GetIPFromContext(ctx),
+		UserAgent:    GetUserAgentFromContext(ctx),
+		OldValue:     modelDeprecationAuditValue(r.deprecations.Find(d.Provider, d.ModelID)),
+		NewValue:     modelDeprecationAuditValue(d),
+	}
+	if err := r.deprecations.Set(ctx, d); err != nil {
+		r.AuditService.LogFailure(ctx, auditEntry, err.Error())
+		return nil, err
+	}
+	r.AuditService.LogSuccess(ctx, auditEntry)
+
+	result := convertModelDeprecationToModel(d)
+	return &result, nil
+}
+
+// ClearModelDeprecation is the resolver for the clearModelDeprecation field.
+func (r *mutationResolver) ClearModelDeprecation(ctx context.Context, provider model.Provider, modelID string) (bool, error) {
+	if r.deprecations == nil {
+		return false, errors.New("model deprecations not configured")
+	}
+
+	p := domain.Provider(strings.ToLower(string(provider)))
+	auditEntry := audit.LogEntry{
+		TenantSlug:   GetTenantFromContext(ctx),
+		Action:       domain.AuditActionDelete,
+		ResourceType: domain.AuditResourceModelDeprecation,
+		ResourceID:   string(p) + "/" + modelID,
+		ResourceName: modelID,
+		Actor:        GetAuditActor(ctx),
+		IPAddress:    GetIPFromContext(ctx),
+		UserAgent:    GetUserAgentFromContext(ctx),
+		OldValue:     modelDeprecationAuditValue(r.deprecations.Find(p, modelID)),
+	}
+	cleared, err := r.deprecations.Clear(ctx, p, modelID)
+	if err != nil {
+		r.AuditService.LogFailure(ctx, auditEntry, err.Error())
+		return false, err
+	}
+	if cleared {
+		r.AuditService.LogSuccess(ctx, auditEntry)
+	}
+	return cleared, nil
+}
+
+// AddCustomDomain is the resolver for the addCustomDomain field.
+func (r *mutationResolver) AddCustomDomain(ctx context.Context, input model.AddCustomDomainInput) (*model.CustomDomain, error) {
+	if r.domains == nil {
+		return nil, errors.New("custom domains not enabled")
+	}
+
+	d := &domain.CustomDomain{
+		Hostname:   input.Hostname,
+		TenantSlug: derefStr(input.TenantSlug),
+		CertPEM:    derefStr(input.CertificatePem),
+		KeyPEM:     derefStr(input.PrivateKeyPem),
+		CreatedBy:  GetUserEmailFromContext(ctx),
+	}
+	auditEntry := audit.LogEntry{
+		TenantSlug:   GetTenantFromContext(ctx),
+		Action:       domain.AuditActionCreate,
+		ResourceType: domain.AuditResourceCustomDomain,
+		ResourceName: input.Hostname,
+		Actor:        GetAuditActor(ctx),
+		IPAddress:    GetIPFromContext(ctx),
+		UserAgent:    GetUserAgentFromContext(ctx),
+	}
+	if err := r.domains.Add(ctx, d); err != nil {
+		r.AuditService.LogFailure(ctx, auditEntry, err.Error())
+		return nil, err
+	}
+	auditEntry.ResourceID = d.ID
+	auditEntry.NewValue = customDomainAuditValue(d)
+	r.AuditService.LogSuccess(ctx, auditEntry)
+
+	result := convertCustomDomainToModel(d)
+	return &result, nil
+}
+
+// UpdateCustomDomain is the resolver for the updateCustomDomain field.
+func (r *mutationResolver) UpdateCustomDomain(ctx context.Context, id string, input model.UpdateCustomDomainInput) (*model.CustomDomain, error) {
+	if r.domains == nil {
+		return nil, errors.New("custom domains not enabled")
+	}
+
+	old, err := r.domains.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	auditEntry := audit.LogEntry{
+		TenantSlug:   GetTenantFromContext(ctx),
+		Action:       domain.AuditActionUpdate,
+		ResourceType: domain.AuditResourceCustomDomain,
+		ResourceID:   id,
+		ResourceName: old.Hostname,
+		Actor:        GetAuditActor(ctx),
+		IPAddress:    GetIPFromContext(ctx),
+		UserAgent:    GetUserAgentFromContext(ctx),
+		OldValue:     customDomainAuditValue(old),
+	}
+	d, err := r.domains.Update(ctx, id, customdomains.Update{
+		TenantSlug: input.TenantSlug,
+		Enabled:    input.Enabled,
+		CertPEM:    derefStr(input.CertificatePem),
+		KeyPEM:     derefStr(input.PrivateKeyPem),
+		UseACME:    input.UseAcme != nil && *input.UseAcme,
+	})
+	if err != nil {
+		r.AuditService.LogFailure(ctx, auditEntry, err.Error())
+		return nil, err
+	}
+	auditEntry.NewValue = customDomainAuditValue(d)
+	r.AuditService.LogSuccess(ctx, auditEntry)
+
+	result := convertCustomDomainToModel(d)
+	return &result, nil
+}
+
+// DeleteCustomDomain is the resolver for the deleteCustomDomain field.
+func (r *mutationResolver) DeleteCustomDomain(ctx context.Context, id string) (bool, error) {
+	if r.domains == nil {
+		return false, errors.New("custom domains not enabled")
+	}
+
+	auditEntry := audit.LogEntry{
+		TenantSlug:   GetTenantFromContext(ctx),
+		Action:       domain.AuditActionDelete,
+		ResourceType: domain.AuditResourceCustomDomain,
+		ResourceID:   id,
+		Actor:        GetAuditActor(ctx),
+		IPAddress:    GetIPFromContext(ctx),
+		UserAgent:    GetUserAgentFromContext(ctx),
+	}
+	d, err := r.domains.Delete(ctx, id)
+	if errors.Is(err, customdomains.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		r.AuditService.LogFailure(ctx, auditEntry, err.Error())
+		return false, err
+	}
+	auditEntry.ResourceName = d.Hostname
+	auditEntry.OldValue = customDomainAuditValue(d)
+	r.AuditService.LogSuccess(ctx, auditEntry)
+	return true, nil
+}
+
+// AddInjectionPattern is the resolver for the addInjectionPattern field.
+func (r *mutationResolver) AddInjectionPattern(ctx context.Context, text string, category *model.InjectionCategory) (*model.InjectionPattern, error) {
+	if r.injection == nil {
+		return nil, errors.New("injection detection not configured")
+	}
+
+	var cat domain.InjectionCategory
+	if category != nil {
+		cat = domain.InjectionCategory(strings.ToLower(string(*category)))
+	}
+	p, err := r.injection.Add(ctx, text, cat, injectionActor(ctx))
+	if err != nil {
+		return nil, err
+	}
+	result := convertInjectionPatternToModel(p)
+	return &result, nil
+}
+
+// DeleteInjectionPattern is the resolver for the deleteInjectionPattern field.
+func (r *mutationResolver) DeleteInjectionPattern(ctx context.Context, id string) (bool, error) {
+	if r.injection == nil {
+		return false, errors.New("injection detection not configured")
+	}
+
+	if _, err := r.injection.Delete(ctx, id, injectionActor(ctx)); err != nil {
+		if errors.Is(err, injection.ErrPatternNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// ReloadConfig is the resolver for the reloadConfig field.
+func (r *mutationResolver) ReloadConfig(ctx context.Context) (*model.ConfigReloadResult, error) {
+	tenantSlug := GetTenantFromContext(ctx)
+	if tenantSlug == "" {
+		return nil, errors.New("tenant context required")
+	}
+	if r.configHolder == nil {
+		return nil, errors.New("config reload not configured")
+	}
+
+	auditEntry := audit.LogEntry{
+		TenantSlug:   tenantSlug,
+		Action:       domain.AuditActionUpdate,
+		ResourceType: domain.AuditResourceConfig,
+		ResourceName: "config.toml",
+		Actor:        GetAuditActor(ctx),
+		IPAddress:    GetIPFromContext(ctx),
+		UserAgent:    GetUserAgentFromContext(ctx),
+	}
+	result, err := r.configHolder.Reload()
+	if err != nil {
+		r.AuditService.LogFailure(ctx, auditEntry, err.Error())
+		return nil, err
+	}
+
+	auditEntry.Details = map[string]any{
+		"changed":          result.Changed,
+		"restart_required": result.RestartRequired,
+	}
+	r.AuditService.LogSuccess(ctx, auditEntry)
+
+	return &model.ConfigReloadResult{
+		Changed:         append([]string{}, result.Changed...),
+		RestartRequired: append([]string{}, result.RestartRequired...),
+		ReloadedAt:      time.Now(),
+	}, nil
+}
+
+// ApplyConfigDocument is the resolver for the applyConfigDocument field.
+func (r *mutationResolver) ApplyConfigDocument(ctx context.Context, document string, dryRun *bool, prune *bool) (*model.ConfigSyncResult, error) {
+	tenantSlug := GetTenantFromContext(ctx)
+	if tenantSlug == "" {
+		return nil, errors.New("tenant context required")
+	}
+	tenantStore, err := r.PGStore.GetTenantStore(tenantSlug)
+	if err != nil {
+		return nil, fmt.Errorf("getting tenant store: %w", err)
+	}
+
+	doc, err := configsync.Parse([]byte(document))
+	if err != nil {
+		return nil, err
+	}
+	actor := GetAuditActor(ctx)
+	opts := configsync.Options{
+		DryRun:     dryRun != nil && *dryRun,
+		Prune:      prune != nil && *prune,
+		ActorID:    actor.ID,
+		ActorEmail: actor.Email,
+	}
+	changes, applyErr := configsync.NewSyncer(tenantStore).Apply(ctx, doc, opts)
+
+	result := &model.ConfigSyncResult{DryRun: opts.DryRun, Changes: make([]model.ConfigSyncChange, 0, len(changes))}
+	providersChanged := false
+	for _, c := range changes {
+		result.Changes = append(result.Changes, convertConfigSyncChangeToModel(c))
+		if opts.DryRun {
+			continue
+		}
+		providersChanged = providersChanged || c.Kind == configsync.KindProvider
+		r.AuditService.LogSuccess(ctx, audit.LogEntry{
+			TenantSlug:   tenantSlug,
+			Action:       domain.AuditAction(c.Action),
+			ResourceType: domain.AuditResourceType(c.Kind),
+			ResourceID:   c.ID,
+			ResourceName: c.Name,
+			Actor:        actor,
+			IPAddress:    GetIPFromContext(ctx),
+			UserAgent:    GetUserAgentFromContext(ctx),
+			Details:      map[string]any{"source": "sync", "fields": c.Fields},
+		})
+	}
+	if providersChanged && r.Gateway != nil {
+		r.Gateway.InvalidateTenantProviderClients(tenantSlug)
+	}
+	if applyErr != nil {
+		r.AuditService.LogFailure(ctx, audit.LogEntry{
+			TenantSlug:   tenantSlug,
+			Action:       domain.AuditActionUpdate,
+			ResourceType: domain.AuditResourceConfig,
+			ResourceName: "config document",
+			Actor:        actor,
+			IPAddress:    GetIPFromContext(ctx),
+			UserAgent:    GetUserAgentFromContext(ctx),
+		}, applyErr.Error())
+		return nil, applyErr
+	}
+	return result, nil
+}
+
+// ImportLiteLLMConfig is the resolver for the importLiteLLMConfig field.
+func (r *mutationResolver) ImportLiteLLMConfig(ctx context.Context, config string, dryRun *bool) (*model.LiteLLMImportResult, error) {
+	tenantSlug := GetTenantFromContext(ctx)
+	if tenantSlug == "" {
+		return nil, errors.New("tenant context required")
+	}
+	tenantStore, err := r.PGStore.GetTenantStore(tenantSlug)
+	if err != nil {
+		return nil, fmt.Errorf("getting tenant store: %w", err)
+	}
+
+	cfg, err := litellm.Parse([]byte(config))
+	if err != nil {
+		return nil, err
+	}
+	actor := GetAuditActor(ctx)
+	opts := litellm.Options{DryRun: dryRun != nil && *dryRun, ActorID: actor.ID, ActorEmail: actor.Email}
+	report, importErr := litellm.NewImporter(tenantStore).Import(ctx, cfg, opts)
+	if report == nil {
+		return nil, importErr
+	}
+
+	providersChanged := false
+	for _, c := range report.Changes {
+		if opts.DryRun {
+			continue
+		}
+		providersChanged = providersChanged || c.Kind == configsync.KindProvider || c.Kind == litellm.KindCustomModel
+		r.AuditService.LogSuccess(ctx, audit.LogEntry{
+			TenantSlug:   tenantSlug,
+			Action:       domain.AuditAction(c.Action),
+			ResourceType: domain.AuditResourceType(c.Kind),
+			ResourceID:   c.ID,
+			ResourceName: c.Name,
+			Actor:        actor,
+			IPAddress:    GetIPFromContext(ctx),
+			UserAgent:    GetUserAgentFromContext(ctx),
+			Details:      map[string]any{"source": "litellm_import", "fields": c.Fields},
+		})
+	}
+	if providersChanged && r.Gateway != nil {
+		r.Gateway.InvalidateTenantProviderClients(tenantSlug)
+	}
+	if importErr != nil {
+		r.AuditService.LogFailure(ctx, audit.LogEntry{
+			TenantSlug:   tenantSlug,
+			Action:       domain.AuditActionCreate,
+			ResourceType: domain.AuditResourceConfig,
+			ResourceName: "LiteLLM import",
+			Actor:        actor,
+			IPAddress:    GetIPFromContext(ctx),
+			UserAgent:    GetUserAgentFromContext(ctx),
+		}, importErr.Error())
+		return nil, importErr
+	}
+	return convertLiteLLMImportReportToModel(report, opts.DryRun), nil
+}
+
+// SetModelPriceOverride is the resolver for the setModelPriceOverride field.
+func (r *mutationResolver) SetModelPriceOverride(ctx context.Context, input model.SetModelPriceOverrideInput) (*model.ModelPrice, error) {
+	return r.setModelPriceOverride(ctx, pricing.Override{
+		Provider:        domain.Provider(strings.ToLower(string(input.Provider))),
+		ModelID:         input.ModelID,
+		InputCostPer1M:  input.InputCostPer1m,
+		OutputCostPer1M: input.OutputCostPer1m,
+		EffectiveFrom:   derefTime(input.EffectiveFrom),
+		Note:            derefStr(input.Note),
+	})
+}
+
+// ClearModelPriceOverride is the resolver for the clearModelPriceOverride field.
+func (r *mutationResolver) ClearModelPriceOverride(ctx context.Context, provider model.Provider, modelID string, effectiveFrom *time.Time, note *string) (*model.ModelPrice, error) {
+	return r.setModelPriceOverride(ctx, pricing.Override{
+		Provider:      domain.Provider(strings.ToLower(string(provider))),
+		ModelID:       modelID,
+		Reset:         true,
+		EffectiveFrom: derefTime(effectiveFrom),
+		Note:          derefStr(note),
+	})
+}
+
+// RefreshModelPrices is the resolver for the refreshModelPrices field.
+func (r *mutationResolver) RefreshModelPrices(ctx context.Context) (int, error) {
+	if GetTenantFromContext(ctx) == "" {
+		return 0, errors.New("tenant context required")
+	}
+	if r.pricing == nil {
+		return 0, errors.New("pricing not configured")
+	}
+	return r.pricing.Refresh(ctx)
+}
+
+// TriggerUsageExport is the resolver for the triggerUsageExport field.
+func (r *mutationResolver) TriggerUsageExport(ctx context.Context, from *time.Time, to *time.Time) ([]model.UsageExportFile, error) {
+	if GetTenantFromContext(ctx) == "" {
+		return nil, errors.New("tenant context required")
+	}
+	if r.usageExport == nil {
+		return nil, errors.New("usage export not configured")
+	}
+
+	var results []usageexport.Result
+	var err error
+	switch {
+	case from == nil && to == nil:
+		results, err = r.usageExport.Run(ctx)
+	case from != nil && to != nil:
+		results, err = r.usageExport.ExportRange(ctx, *from, *to)
+	default:
+		return nil, errors.New("from and to must be given together")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("usage export failed: %w", err)
+	}
+
+	files := make([]model.UsageExportFile, len(results))
+	for i, res := range results {
+		files[i] = convertUsageExportResultToModel(res)
+	}
+	return files, nil
+}
+
+// ReplayRequest is the resolver for the replayRequest field.
+func (r *mutationResolver) ReplayRequest(ctx context.Context, id string, model *string) (*model.ReplayResult, error) {
+	tenantSlug := GetTenantFromContext(ctx)
+	if tenantSlug == "" {
+		return nil, errors.New("tenant context required")
+	}
+	if r.replay == nil {
+		return nil, errors.New("request replay not configured")
+	}
+
+	auditEntry := audit.LogEntry{
+		TenantSlug:   tenantSlug,
+		Action:       domain.AuditActionReplay,
+		ResourceType: domain.AuditResourceRequestLog,
+		ResourceID:   id,
+		Actor:        GetAuditActor(ctx),
+		IPAddress:    GetIPFromContext(ctx),
+		UserAgent:    GetUserAgentFromContext(ctx),
+		Details:      map[string]any{"model_override": derefStr(model)},
+	}
+	result, err := r.replay.Replay(ctx, id, derefStr(model))
+	if err != nil {
+		r.AuditService.LogFailure(ctx, auditEntry, err.Error())
+		return nil, err
+	}
+	auditEntry.Details["replay_request_id"] = result.RequestID
+	r.AuditService.LogSuccess(ctx, auditEntry)
 
-	return true, nil
+	return convertReplayResultToModel(result), nil
 }
 
-// CreateBudgetAlert is the resolver for the createBudgetAlert field.
-func (r *mutationResolver) CreateBudgetAlert(ctx context.Context, input model.CreateBudgetAlertInput) (*model.BudgetAlert, error) {
-	enabled := true
-	if input.Enabled != nil {
-		enabled = *input.Enabled
+// StartAuditLogExport is the resolver for the startAuditLogExport field.
+func (r *mutationResolver) StartAuditLogExport(ctx context.Context, filter *model.AuditLogFilter, format model.AuditExportFormat) (*model.AuditExportJob, error) {
+	tenantSlug := GetTenantFromContext(ctx)
+	if tenantSlug == "" {
+		return nil, errors.New("tenant context required")
 	}
-	return &model.BudgetAlert{
-		ID:            uuid.New().String(),
-		Name:          input.Name,
-		Type:          input.Type,
-		Threshold:     input.Threshold,
-		ThresholdType: input.ThresholdType,
-		Period:        input.Period,
-		Enabled:       enabled,
-		CreatedAt:     time.Now(),
-	}, nil
-}
-
-// UpdateBudgetAlert is the resolver for the updateBudgetAlert field.
-func (r *mutationResolver) UpdateBudgetAlert(ctx context.Context, id string, input model.UpdateBudgetAlertInput) (*model.BudgetAlert, error) {
-	name := ""
-	if input.Name != nil {
-		name = *input.Name
+	if r.auditExport == nil {
+		return nil, errors.New("audit export not configured")
 	}
-	threshold := 0.0
-	if input.Threshold != nil {
-		threshold = *input.Threshold
+
+	domainFilter := convertAuditLogFilter(filter)
+	auditEntry := audit.LogEntry{
+		TenantSlug:   tenantSlug,
+		Action:       domain.AuditActionExport,
+		ResourceType: domain.AuditResourceAuditLog,
+		Actor:        GetAuditActor(ctx),
+		IPAddress:    GetIPFromContext(ctx),
+		UserAgent:    GetUserAgentFromContext(ctx),
+		Details: map[string]any{
+			"format": strings.ToLower(string(format)),
+			"filter": filter,
+		},
 	}
-	enabled := true
-	if input.Enabled != nil {
-		enabled = *input.Enabled
+	job, err := r.auditExport.Start(ctx, domainFilter, strings.ToLower(string(format)), GetUserEmailFromContext(ctx))
+	if err != nil {
+		r.AuditService.LogFailure(ctx, auditEntry, err.Error())
+		return nil, err
 	}
-	return &model.BudgetAlert{
-		ID:        id,
-		Name:      name,
-		Threshold: threshold,
-		Enabled:   enabled,
-		CreatedAt: time.Now(),
-	}, nil
-}
+	auditEntry.ResourceID = job.ID
+	r.AuditService.LogSuccess(ctx, auditEntry)
 
-// DeleteBudgetAlert is the resolver for the deleteBudgetAlert field.
-func (r *mutationResolver) DeleteBudgetAlert(ctx context.Context, id string) (bool, error) {
-	return true, nil
+	result := convertAuditExportJobToModel(job)
+	return &result, nil
 }
 
 // SetToolPermission is the resolver for the setToolPermission field.
@@ -1634,6 +3693,9 @@ func (r *mutationResolver) SetMCPPermission(ctx context.Context, input model.Set
 	if err := store.SetMCPToolPermission(ctx, perm); err != nil {
 		return nil, err
 	}
+	if r.toolApprovals != nil {
+		r.toolApprovals.Settle(ctx, perm)
+	}
 
 	// Sync to role_tools if visibility is ALLOW or SEARCH
 	if perm.Visibility == domain.MCPVisibilityAllow || perm.Visibility == domain.MCPVisibilitySearch {
@@ -1695,6 +3757,84 @@ func (r *mutationResolver) BulkSetMCPVisibility(ctx context.Context, roleID stri
 	return count, nil
 }
 
+// ApproveMCPTool is the resolver for the approveMCPTool field.
+func (r *mutationResolver) ApproveMCPTool(ctx context.Context, input model.ApproveMCPToolInput) (*model.MCPToolApproval, error) {
+	if r.toolApprovals == nil {
+		return nil, toolapproval.ErrNotFound
+	}
+
+	var visibility domain.MCPToolVisibility
+	if input.Visibility != nil {
+		visibility = domain.MCPToolVisibility(*input.Visibility)
+	}
+	reason := ""
+	if input.Reason != nil {
+		reason = *input.Reason
+	}
+	actorID, actorEmail, _ := getActorInfoFromContext(ctx)
+	entry := audit.LogEntry{
+		TenantSlug:   GetTenantFromContext(ctx),
+		Action:       domain.AuditActionApprove,
+		ResourceType: domain.AuditResourceMCPToolApproval,
+		ResourceID:   input.ApprovalID,
+		Actor:        GetAuditActor(ctx),
+		IPAddress:    GetIPFromContext(ctx),
+		UserAgent:    GetUserAgentFromContext(ctx),
+	}
+	approval, err := r.toolApprovals.Approve(ctx, input.ApprovalID, visibility, reason, actorID, actorEmail)
+	if err != nil {
+		r.AuditService.LogFailure(ctx, entry, err.Error())
+		return nil, err
+	}
+	entry.ResourceName = approval.ToolName
+	entry.NewValue = map[string]any{
+		"status":     approval.Status,
+		"role":       approval.RoleName,
+		"visibility": approval.Visibility,
+		"reason":     approval.DecisionReason,
+	}
+	r.AuditService.LogSuccess(ctx, entry)
+
+	// Approved tools join the role's tool list like ones allowed directly
+	if store, err := r.PGStore.GetTenantStore(GetTenantFromContext(ctx)); err == nil {
+		if err := r.syncMCPToolToRoleTools(ctx, store, approval.RoleID, approval.ToolID, actorID); err != nil {
+			slog.Warn("Failed to sync approved MCP tool to role_tools", "tool_id", approval.ToolID, "role_id", approval.RoleID, "error", err)
+		}
+	}
+	return convertMCPToolApprovalToModel(approval), nil
+}
+
+// DenyMCPTool is the resolver for the denyMCPTool field.
+func (r *mutationResolver) DenyMCPTool(ctx context.Context, input model.DenyMCPToolInput) (*model.MCPToolApproval, error) {
+	if r.toolApprovals == nil {
+		return nil, toolapproval.ErrNotFound
+	}
+
+	actorID, actorEmail, _ := getActorInfoFromContext(ctx)
+	entry := audit.LogEntry{
+		TenantSlug:   GetTenantFromContext(ctx),
+		Action:       domain.AuditActionReject,
+		ResourceType: domain.AuditResourceMCPToolApproval,
+		ResourceID:   input.ApprovalID,
+		Actor:        GetAuditActor(ctx),
+		IPAddress:    GetIPFromContext(ctx),
+		UserAgent:    GetUserAgentFromContext(ctx),
+	}
+	approval, err := r.toolApprovals.Deny(ctx, input.ApprovalID, input.Reason, actorID, actorEmail)
+	if err != nil {
+		r.AuditService.LogFailure(ctx, entry, err.Error())
+		return nil, err
+	}
+	entry.ResourceName = approval.ToolName
+	entry.NewValue = map[string]any{
+		"status": approval.Status,
+		"role":   approval.RoleName,
+		"reason": approval.DecisionReason,
+	}
+	r.AuditService.LogSuccess(ctx, entry)
+	return convertMCPToolApprovalToModel(approval), nil
+}
+
 // AddToolExample adds an example to a tool
 func (r *mutationResolver) AddToolExample(ctx context.Context, toolID string, example map[string]any) (*model.MCPTool, error) {
 	tenantSlug := GetTenantFromContext(ctx)
@@ -1788,24 +3928,7 @@ func (r *providerConfigResolver) APIKeys(ctx context.Context, obj *model.Provide
 	// Convert to GraphQL model
 	result := make([]model.ProviderAPIKey, len(keys))
 	for i, key := range keys {
-		result[i] = model.ProviderAPIKey{
-			ID:                 key.ID,
-			Provider:           obj.Provider,
-			Name:               &key.Name,
-			KeyPrefix:          key.KeyPrefix,
-			CredentialType:     key.CredentialType,
-			Priority:           key.Priority,
-			Enabled:            key.Enabled,
-			HealthScore:        key.HealthScore,
-			SuccessCount:       key.SuccessCount,
-			FailureCount:       key.FailureCount,
-			RateLimitRemaining: key.RateLimitRemaining,
-			RateLimitResetAt:   key.RateLimitResetAt,
-			RequestCount:       int(key.RequestCount),
-			LastUsedAt:         key.LastUsedAt,
-			CreatedAt:          key.CreatedAt,
-			UpdatedAt:          key.UpdatedAt,
-		}
+		result[i] = providerAPIKeyToModel(ks, key, obj.Provider)
 	}
 
 	return result, nil
@@ -1819,14 +3942,46 @@ func (r *queryResolver) Me(ctx context.Context) (*model.User, error) {
 		return nil, errors.New("unauthorized: not logged in")
 	}
 
-	return &model.User{
+	result := &model.User{
 		ID:        user.ID,
 		Email:     user.Email,
 		Name:      user.Name,
 		Role:      string(user.Role),
 		Status:    string(user.Status),
 		CreatedAt: user.CreatedAt,
-	}, nil
+	}
+	if stored, err := r.PGStore.TenantStore().GetUser(ctx, user.ID); err == nil && stored != nil {
+		result.APIRoleID = optionalStr(stored.APIRoleID)
+		result.DashboardRoleID = optionalStr(stored.DashboardRoleID)
+	}
+	return result, nil
+}
+
+// MyPermissions is the resolver for the myPermissions field.
+func (r *queryResolver) MyPermissions(ctx context.Context) ([]string, error) {
+	perms := PermissionsFromContext(ctx)
+	if perms == nil {
+		perms = []string{}
+	}
+	return perms, nil
+}
+
+// DigestSubscription is the resolver for the digestSubscription field.
+func (r *queryResolver) DigestSubscription(ctx context.Context) (*model.DigestSubscription, error) {
+	userID := GetUserFromContext(ctx)
+	if userID == "" {
+		return nil, errors.New("user context required")
+	}
+	if r.digests == nil {
+		return &model.DigestSubscription{Frequency: model.DigestFrequencyOff}, nil
+	}
+
+	sub, err := r.digests.Subscription(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	result := convertDigestSubscriptionToModel(sub, r.digests.Enabled())
+	return &result, nil
 }
 
 // Tenants is the resolver for the tenants field.
@@ -1893,15 +4048,35 @@ func (r *queryResolver) AdminStats(ctx context.Context) (*model.TenantStats, err
 }
 
 // RegistrationRequests is the resolver for the registrationRequests field.
-// Not supported in single-tenant mode
 func (r *queryResolver) RegistrationRequests(ctx context.Context, status *string) ([]model.RegistrationRequest, error) {
-	return []model.RegistrationRequest{}, nil
+	if r.registration == nil {
+		return []model.RegistrationRequest{}, nil
+	}
+	filter := ""
+	if status != nil {
+		filter = strings.ToLower(*status)
+	}
+	requests, err := r.registration.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]model.RegistrationRequest, 0, len(requests))
+	for _, req := range requests {
+		result = append(result, *convertRegistrationRequestToModel(req))
+	}
+	return result, nil
 }
 
 // RegistrationRequest is the resolver for the registrationRequest field.
-// Not supported in single-tenant mode
 func (r *queryResolver) RegistrationRequest(ctx context.Context, id string) (*model.RegistrationRequest, error) {
-	return nil, errors.New("registration requests not supported in single-tenant mode")
+	if r.registration == nil {
+		return nil, nil
+	}
+	req, err := r.registration.Get(ctx, id)
+	if err != nil || req == nil {
+		return nil, err
+	}
+	return convertRegistrationRequestToModel(req), nil
 }
 
 // Providers is the resolver for the providers field.
@@ -1931,18 +4106,28 @@ func (r *queryResolver) Providers(ctx context.Context) ([]model.ProviderConfig,
 		model.ProviderMistral,
 		model.ProviderTogether,
 		model.ProviderCohere,
+		model.ProviderOpenrouter,
+		model.ProviderXai,
+		model.ProviderDeepseek,
+		model.ProviderHuggingface,
+		model.ProviderFireworks,
+		model.ProviderCustom,
 	}
 
 	// Start with all providers disabled
 	providerMap := make(map[model.Provider]*model.ProviderConfig)
 	for _, p := range allProviders {
 		providerMap[p] = &model.ProviderConfig{
-			Provider:           p,
-			Enabled:            false,
-			HasAPIKey:          false,
-			HasAccessKeys:      false,
-			ConnectionSettings: defaultConnSettings,
-			PlanCeiling:        planCeiling,
+			Provider:             p,
+			Enabled:              false,
+			HasAPIKey:            false,
+			HasAccessKeys:        false,
+			ConnectionSettings:   defaultConnSettings,
+			PlanCeiling:          planCeiling,
+			Throttles:            []model.ProviderThrottle{},
+			Regions:              []model.ProviderRegion{},
+			KeyStrategy:          model.KeyStrategyRoundRobin,
+			HuggingFaceEndpoints: []model.HuggingFaceEndpoint{},
 		}
 	}
 
@@ -1993,6 +4178,10 @@ func (r *queryResolver) Providers(ctx context.Context) ([]model.ProviderConfig,
 
 					// Include connection settings
 					pc.ConnectionSettings = convertDomainConnectionSettingsToModel(cfg.ConnectionSettings)
+					pc.Throttles = convertDomainThrottlesToModel(cfg.Throttles)
+					pc.Regions = convertDomainRegionsToModel(cfg.Provider, cfg.Regions, r.Gateway)
+					pc.KeyStrategy = convertKeyStrategyToModel(cfg.KeyStrategy)
+					pc.HuggingFaceEndpoints = convertDomainHuggingFaceEndpointsToModel(cfg.HuggingFaceEndpoints)
 				}
 			}
 		}
@@ -2007,7 +4196,7 @@ func (r *queryResolver) Providers(ctx context.Context) ([]model.ProviderConfig,
 }
 
 // Models is the resolver for the models field.
-func (r *queryResolver) Models(ctx context.Context) ([]model.Model, error) {
+func (r *queryResolver) Models(ctx context.Context, filter *model.ModelFilterInput) ([]model.Model, error) {
 	// Get tenant from context
 	tenant, ok := ctx.Value("tenant").(*domain.Tenant)
 	if !ok || tenant == nil {
@@ -2026,19 +4215,43 @@ func (r *queryResolver) Models(ctx context.Context) ([]model.Model, error) {
 		return []model.Model{}, nil
 	}
 
+	catalogFilter := convertModelFilterInput(filter)
+
 	// Convert to GraphQL model
 	result := make([]model.Model, 0, len(dbModels))
 	for _, m := range dbModels {
-		result = append(result, model.Model{
+		info := domain.ModelInfo{
 			ID:                m.ModelID,
 			Name:              m.ModelName,
-			Provider:          model.Provider(strings.ToUpper(m.Provider)),
-			Enabled:           m.IsAvailable,
+			Provider:          domain.Provider(m.Provider),
 			SupportsTools:     m.SupportsTools,
-			SupportsStreaming: m.SupportsStreaming,
-			ContextLimit:      m.ContextWindow,
-			InputCostPer1m:    m.InputCostPer1M,
-			OutputCostPer1m:   m.OutputCostPer1M,
+			SupportsReasoning: m.SupportsReasoning,
+			SupportsVision:    m.SupportsVision,
+			ContextLimit:      uint32(m.ContextWindow),
+			OutputLimit:       uint32(m.MaxOutputTokens),
+			InputCostPer1M:    m.InputCostPer1M,
+			OutputCostPer1M:   m.OutputCostPer1M,
+		}
+		if r.Gateway != nil {
+			info = r.Gateway.DescribeModel(ctx, info)
+		}
+		if catalogFilter != nil && !catalogFilter.Matches(info) {
+			continue
+		}
+		result = append(result, model.Model{
+			ID:                   info.ID,
+			Name:                 info.Name,
+			Provider:             model.Provider(strings.ToUpper(m.Provider)),
+			Enabled:              m.IsAvailable,
+			SupportsTools:        info.SupportsTools,
+			SupportsVision:       info.SupportsVision,
+			SupportsReasoning:    info.SupportsReasoning,
+			SupportsStreaming:    m.SupportsStreaming,
+			ContextLimit:         int(info.ContextLimit),
+			OutputLimit:          int(info.OutputLimit),
+			InputCostPer1m:       info.InputCostPer1M,
+			OutputCostPer1m:      info.OutputCostPer1M,
+			CachedInputCostPer1m: info.CachedInputCostPer1M,
 		})
 	}
 
@@ -2047,7 +4260,64 @@ func (r *queryResolver) Models(ctx context.Context) ([]model.Model, error) {
 
 // AvailableModels is the resolver for the availableModels field.
 func (r *queryResolver) AvailableModels(ctx context.Context) ([]model.Model, error) {
-	return r.Models(ctx)
+	return r.Models(ctx, nil)
+}
+
+// OllamaModels is the resolver for the ollamaModels field.
+func (r *queryResolver) OllamaModels(ctx context.Context) ([]model.OllamaModel, error) {
+	if GetTenantFromContext(ctx) == "" {
+		return nil, fmt.Errorf("tenant not specified")
+	}
+	if r.ollama == nil {
+		return []model.OllamaModel{}, nil
+	}
+
+	models, err := r.ollama.Models(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]model.OllamaModel, len(models))
+	for i, m := range models {
+		result[i] = convertOllamaModelToModel(m)
+	}
+	return result, nil
+}
+
+// OllamaPulls is the resolver for the ollamaPulls field.
+func (r *queryResolver) OllamaPulls(ctx context.Context) ([]model.OllamaPull, error) {
+	if GetTenantFromContext(ctx) == "" {
+		return nil, fmt.Errorf("tenant not specified")
+	}
+	if r.ollama == nil {
+		return []model.OllamaPull{}, nil
+	}
+
+	pulls := r.ollama.Pulls()
+	result := make([]model.OllamaPull, len(pulls))
+	for i, p := range pulls {
+		result[i] = convertOllamaPullToModel(p)
+	}
+	return result, nil
+}
+
+// CustomModels is the resolver for the customModels field.
+func (r *queryResolver) CustomModels(ctx context.Context) ([]model.CustomModel, error) {
+	if GetTenantFromContext(ctx) == "" {
+		return nil, fmt.Errorf("tenant not specified")
+	}
+	if r.customModels == nil {
+		return []model.CustomModel{}, nil
+	}
+
+	models, err := r.customModels.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]model.CustomModel, len(models))
+	for i, m := range models {
+		result[i] = convertCustomModelToModel(m)
+	}
+	return result, nil
 }
 
 // Roles is the resolver for the roles field.
@@ -2081,6 +4351,7 @@ func (r *queryResolver) Roles(ctx context.Context) ([]model.Role, error) {
 			Description: &role.Description,
 			IsDefault:   role.IsDefault,
 			IsSystem:    role.IsSystem,
+			Permissions: role.Permissions,
 			Policy:      policyModel,
 			CreatedAt:   role.CreatedAt,
 			UpdatedAt:   role.UpdatedAt,
@@ -2128,6 +4399,7 @@ func (r *queryResolver) Role(ctx context.Context, id string) (*model.Role, error
 		Description: &role.Description,
 		IsDefault:   role.IsDefault,
 		IsSystem:    role.IsSystem,
+		Permissions: role.Permissions,
 		Policy:      policyModel,
 		CreatedAt:   role.CreatedAt,
 		UpdatedAt:   role.UpdatedAt,
@@ -2163,6 +4435,7 @@ func (r *queryResolver) Groups(ctx context.Context) ([]model.Group, error) {
 					Description: &role.Description,
 					IsDefault:   role.IsDefault,
 					IsSystem:    role.IsSystem,
+					Permissions: role.Permissions,
 					CreatedAt:   role.CreatedAt,
 					UpdatedAt:   role.UpdatedAt,
 				})
@@ -2218,6 +4491,7 @@ func (r *queryResolver) Group(ctx context.Context, id string) (*model.Group, err
 				Description: &role.Description,
 				IsDefault:   role.IsDefault,
 				IsSystem:    role.IsSystem,
+				Permissions: role.Permissions,
 				CreatedAt:   role.CreatedAt,
 				UpdatedAt:   role.UpdatedAt,
 			})
@@ -2257,46 +4531,7 @@ func (r *queryResolver) APIKeys(ctx context.Context) ([]model.APIKey, error) {
 	// Convert to GraphQL model
 	result := make([]model.APIKey, 0, len(apiKeysWithRole))
 	for _, keyWithRole := range apiKeysWithRole {
-		gqlKey := model.APIKey{
-			ID:         keyWithRole.APIKey.ID,
-			Name:       keyWithRole.APIKey.Name,
-			KeyPrefix:  keyWithRole.APIKey.KeyPrefix,
-			LastUsedAt: keyWithRole.APIKey.LastUsedAt,
-			CreatedAt:  keyWithRole.APIKey.CreatedAt,
-			ExpiresAt:  keyWithRole.APIKey.ExpiresAt,
-			Revoked:    keyWithRole.APIKey.Revoked,
-		}
-
-		// Check if expired
-		if keyWithRole.APIKey.ExpiresAt != nil && time.Now().After(*keyWithRole.APIKey.ExpiresAt) {
-			gqlKey.IsExpired = true
-		}
-
-		// Add created by info
-		if keyWithRole.APIKey.CreatedBy != "" {
-			gqlKey.CreatedBy = &keyWithRole.APIKey.CreatedBy
-		}
-		if keyWithRole.APIKey.CreatedByEmail != "" {
-			gqlKey.CreatedByEmail = &keyWithRole.APIKey.CreatedByEmail
-		}
-
-		// Add role if present
-		if keyWithRole.RoleID != "" {
-			gqlKey.Role = &model.Role{
-				ID:   keyWithRole.RoleID,
-				Name: keyWithRole.RoleName,
-			}
-		}
-
-		// Add group if present
-		if keyWithRole.GroupID != "" {
-			gqlKey.Group = &model.Group{
-				ID:   keyWithRole.GroupID,
-				Name: keyWithRole.GroupName,
-			}
-		}
-
-		result = append(result, gqlKey)
+		result = append(result, convertAPIKeyWithRoleToModel(keyWithRole))
 	}
 
 	return result, nil
@@ -2322,46 +4557,55 @@ func (r *queryResolver) APIKey(ctx context.Context, id string) (*model.APIKey, e
 		return nil, nil
 	}
 
-	gqlKey := &model.APIKey{
-		ID:         keyWithRole.ID,
-		Name:       keyWithRole.Name,
-		KeyPrefix:  keyWithRole.KeyPrefix,
-		LastUsedAt: keyWithRole.LastUsedAt,
-		CreatedAt:  keyWithRole.CreatedAt,
-		ExpiresAt:  keyWithRole.ExpiresAt,
-		Revoked:    keyWithRole.Revoked,
+	gqlKey := convertAPIKeyWithRoleToModel(keyWithRole)
+	return &gqlKey, nil
+}
+
+// MyAPIKeys is the resolver for the myAPIKeys field.
+func (r *queryResolver) MyAPIKeys(ctx context.Context) ([]model.APIKey, error) {
+	userID := GetUserFromContext(ctx)
+	if userID == "" {
+		return nil, errors.New("user context required")
 	}
 
-	if keyWithRole.ExpiresAt != nil && time.Now().After(*keyWithRole.ExpiresAt) {
-		gqlKey.IsExpired = true
+	keys, err := r.PGStore.TenantStore().ListUserAPIKeys(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
 	}
+	result := make([]model.APIKey, 0, len(keys))
+	for _, k := range keys {
+		result = append(result, convertAPIKeyWithRoleToModel(k))
+	}
+	return result, nil
+}
 
-	if keyWithRole.CreatedBy != "" {
-		gqlKey.CreatedBy = &keyWithRole.CreatedBy
+// Users is the resolver for the users field.
+func (r *queryResolver) Users(ctx context.Context) ([]model.User, error) {
+	tenantSlug := GetTenantFromContext(ctx)
+	if tenantSlug == "" {
+		return nil, fmt.Errorf("tenant not specified")
 	}
-	if keyWithRole.CreatedByEmail != "" {
-		gqlKey.CreatedByEmail = &keyWithRole.CreatedByEmail
+
+	tenantStore, err := r.PGStore.GetTenantStore(tenantSlug)
+	if err != nil {
+		return nil, fmt.Errorf("getting tenant store: %w", err)
 	}
 
-	if keyWithRole.RoleID != "" {
-		gqlKey.Role = &model.Role{
-			ID:   keyWithRole.RoleID,
-			Name: keyWithRole.RoleName,
-		}
+	users, err := tenantStore.ListUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing users: %w", err)
 	}
 
-	if keyWithRole.GroupID != "" {
-		gqlKey.Group = &model.Group{
-			ID:   keyWithRole.GroupID,
-			Name: keyWithRole.GroupName,
-		}
+	result := make([]model.User, len(users))
+	for i, u := range users {
+		result[i] = convertTenantUserToModel(u)
 	}
 
-	return gqlKey, nil
+	return result, nil
 }
 
-// Users is the resolver for the users field.
-func (r *queryResolver) Users(ctx context.Context) ([]model.User, error) {
+// User is the resolver for the user field.
+func (r *queryResolver) User(ctx context.Context, id string) (*model.User, error) {
 	tenantSlug := GetTenantFromContext(ctx)
 	if tenantSlug == "" {
 		return nil, fmt.Errorf("tenant not specified")
@@ -2372,45 +4616,155 @@ func (r *queryResolver) Users(ctx context.Context) ([]model.User, error) {
 		return nil, fmt.Errorf("getting tenant store: %w", err)
 	}
 
-	users, err := tenantStore.ListUsers(ctx)
+	user, err := tenantStore.GetUser(ctx, id)
 	if err != nil {
-		return nil, fmt.Errorf("listing users: %w", err)
+		return nil, fmt.Errorf("getting user: %w", err)
+	}
+	if user == nil {
+		return nil, nil
+	}
+
+	result := convertTenantUserToModel(user)
+	return &result, nil
+}
+
+// TwoFactorSettings is the resolver for the twoFactorSettings field.
+func (r *queryResolver) TwoFactorSettings(ctx context.Context) (*model.TwoFactorSettings, error) {
+	if r.twoFactor == nil {
+		return &model.TwoFactorSettings{}, nil
+	}
+	settings, err := r.twoFactor.Settings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := convertTwoFactorSettingsToModel(settings)
+	return &result, nil
+}
+
+// Projects is the resolver for the projects field.
+func (r *queryResolver) Projects(ctx context.Context) ([]model.Project, error) {
+	if GetTenantFromContext(ctx) == "" {
+		return nil, fmt.Errorf("tenant not specified")
+	}
+	if r.projects == nil {
+		return []model.Project{}, nil
+	}
+
+	list, err := r.projects.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]model.Project, 0, len(list))
+	for _, p := range list {
+		spend, err := r.projects.Spend(ctx, p.ID)
+		if err != nil {
+			log.Printf("Failed to get spend of project %s: %v", p.ID, err)
+		}
+		result = append(result, convertProjectToModel(p, spend))
+	}
+	return result, nil
+}
+
+// Project is the resolver for the project field.
+func (r *queryResolver) Project(ctx context.Context, id string) (*model.Project, error) {
+	if GetTenantFromContext(ctx) == "" {
+		return nil, fmt.Errorf("tenant not specified")
+	}
+	if r.projects == nil {
+		return nil, errors.New("projects not configured")
+	}
+
+	p, err := r.projects.Get(ctx, id)
+	if errors.Is(err, projects.ErrProjectNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	spend, err := r.projects.Spend(ctx, p.ID)
+	if err != nil {
+		log.Printf("Failed to get spend of project %s: %v", p.ID, err)
+	}
+	result := convertProjectToModel(p, spend)
+	return &result, nil
+}
+
+// ProjectUsage is the resolver for the projectUsage field.
+func (r *queryResolver) ProjectUsage(ctx context.Context, startDate *time.Time, endDate *time.Time) ([]model.ProjectUsage, error) {
+	if GetTenantFromContext(ctx) == "" {
+		return nil, fmt.Errorf("tenant not specified")
+	}
+	if r.projects == nil {
+		return []model.ProjectUsage{}, nil
+	}
+
+	// Default to the current month, like the dashboard
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	end := now
+	if startDate != nil {
+		start = *startDate
+	}
+	if endDate != nil {
+		end = *endDate
 	}
 
-	result := make([]model.User, len(users))
-	for i, u := range users {
-		result[i] = convertTenantUserToModel(u)
+	stats, err := r.projects.Usage(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+	var totalCost float64
+	for _, ps := range stats {
+		totalCost += ps.CostUSD
+	}
+	result := make([]model.ProjectUsage, 0, len(stats))
+	for _, ps := range stats {
+		var percentage float64
+		if totalCost > 0 {
+			percentage = ps.CostUSD / totalCost * 100
+		}
+		result = append(result, model.ProjectUsage{
+			ProjectID:   ps.ProjectID,
+			ProjectName: ps.ProjectName,
+			Requests:    int(ps.Requests),
+			Tokens:      int(ps.TotalTokens),
+			Cost:        ps.CostUSD,
+			Percentage:  percentage,
+		})
 	}
-
 	return result, nil
 }
 
-// User is the resolver for the user field.
-func (r *queryResolver) User(ctx context.Context, id string) (*model.User, error) {
-	tenantSlug := GetTenantFromContext(ctx)
-	if tenantSlug == "" {
+// SpendForecast is the resolver for the spendForecast field.
+func (r *queryResolver) SpendForecast(ctx context.Context) (*model.SpendForecastReport, error) {
+	if GetTenantFromContext(ctx) == "" {
 		return nil, fmt.Errorf("tenant not specified")
 	}
-
-	tenantStore, err := r.PGStore.GetTenantStore(tenantSlug)
-	if err != nil {
-		return nil, fmt.Errorf("getting tenant store: %w", err)
+	if r.forecaster == nil {
+		return &model.SpendForecastReport{APIKeys: []model.SpendForecast{}, Roles: []model.SpendForecast{}}, nil
 	}
 
-	user, err := tenantStore.GetUser(ctx, id)
+	report, err := r.forecaster.Forecast(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("getting user: %w", err)
+		return nil, err
 	}
-	if user == nil {
-		return nil, nil
+	result := &model.SpendForecastReport{
+		PeriodStart: report.PeriodStart,
+		PeriodEnd:   report.PeriodEnd,
+		APIKeys:     make([]model.SpendForecast, 0, len(report.APIKeys)),
+		Roles:       make([]model.SpendForecast, 0, len(report.Roles)),
 	}
-
-	result := convertTenantUserToModel(user)
-	return &result, nil
+	for _, fc := range report.APIKeys {
+		result.APIKeys = append(result.APIKeys, convertSpendForecastToModel(fc))
+	}
+	for _, fc := range report.Roles {
+		result.Roles = append(result.Roles, convertSpendForecastToModel(fc))
+	}
+	return result, nil
 }
 
 // Dashboard is the resolver for the dashboard field.
-func (r *queryResolver) Dashboard(ctx context.Context) (*model.DashboardStats, error) {
+func (r *queryResolver) Dashboard(ctx context.Context, projectID *string) (*model.DashboardStats, error) {
 	// Get tenant from context
 	tenant, ok := ctx.Value("tenant").(*domain.Tenant)
 	if !ok || tenant == nil {
@@ -2433,6 +4787,9 @@ func (r *queryResolver) Dashboard(ctx context.Context) (*model.DashboardStats, e
 			TopModels:         []model.ModelUsage{},
 			ProviderBreakdown: []model.ProviderUsage{},
 			APIKeyBreakdown:   []model.APIKeyUsage{},
+			UserBreakdown:     []model.UserUsage{},
+			FeedbackByModel:   []model.FeedbackStats{},
+			FeedbackByRole:    []model.FeedbackStats{},
 		}, nil
 	}
 
@@ -2443,35 +4800,35 @@ func (r *queryResolver) Dashboard(ctx context.Context) (*model.DashboardStats, e
 	startOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
 
 	// Get overall stats for the current month
-	stats, err := r.PGStore.GetUsageStats(ctx, startOfMonth, now)
+	stats, err := r.PGStore.GetUsageStats(ctx, startOfMonth, now, derefStr(projectID))
 	if err != nil {
 		log.Printf("Failed to get usage stats: %v", err)
 		stats = &domain.UsageStats{}
 	}
 
 	// Get hourly stats for today
-	hourlyPoints, err := r.PGStore.GetUsageTimeSeries(ctx, startOfDay, now, "hour")
+	hourlyPoints, err := r.PGStore.GetUsageTimeSeries(ctx, startOfDay, now, "hour", derefStr(projectID))
 	if err != nil {
 		log.Printf("Failed to get hourly stats: %v", err)
 		hourlyPoints = []*domain.UsageTimePoint{}
 	}
 
 	// Get weekly cost trend
-	dailyPoints, err := r.PGStore.GetUsageTimeSeries(ctx, startOfWeek, now, "day")
+	dailyPoints, err := r.PGStore.GetUsageTimeSeries(ctx, startOfWeek, now, "day", derefStr(projectID))
 	if err != nil {
 		log.Printf("Failed to get daily stats: %v", err)
 		dailyPoints = []*domain.UsageTimePoint{}
 	}
 
 	// Get top models
-	modelStats, err := r.PGStore.GetUsageStatsByModel(ctx, startOfMonth, now)
+	modelStats, err := r.PGStore.GetUsageStatsByModel(ctx, startOfMonth, now, derefStr(projectID))
 	if err != nil {
 		log.Printf("Failed to get model stats: %v", err)
 		modelStats = make(map[string]*domain.ModelUsageStats)
 	}
 
 	// Get provider breakdown
-	providerStats, err := r.PGStore.GetUsageStatsByProvider(ctx, startOfMonth, now)
+	providerStats, err := r.PGStore.GetUsageStatsByProvider(ctx, startOfMonth, now, derefStr(projectID))
 	if err != nil {
 		log.Printf("Failed to get provider stats: %v", err)
 		providerStats = make(map[string]*domain.ProviderUsageStats)
@@ -2565,7 +4922,7 @@ func (r *queryResolver) Dashboard(ctx context.Context) (*model.DashboardStats, e
 	}
 
 	// Get API key breakdown
-	apiKeyStats, err := r.PGStore.GetUsageStatsByAPIKey(ctx, startOfMonth, now)
+	apiKeyStats, err := r.PGStore.GetUsageStatsByAPIKey(ctx, startOfMonth, now, derefStr(projectID))
 	if err != nil {
 		log.Printf("Failed to get API key stats: %v", err)
 		apiKeyStats = make(map[string]*domain.APIKeyUsageStats)
@@ -2599,6 +4956,33 @@ func (r *queryResolver) Dashboard(ctx context.Context) (*model.DashboardStats, e
 		}
 	}
 
+	// Get personal API key usage by owner
+	userStats, err := r.PGStore.TenantStore().GetUsageStatsByUser(ctx, startOfMonth, now, derefStr(projectID))
+	if err != nil {
+		log.Printf("Failed to get user stats: %v", err)
+	}
+	userBreakdown := make([]model.UserUsage, 0, len(userStats))
+	for _, us := range userStats {
+		userBreakdown = append(userBreakdown, model.UserUsage{
+			UserID:     us.UserID,
+			Email:      us.Email,
+			Requests:   int(us.Requests),
+			Tokens:     int(us.TotalTokens),
+			Cost:       us.CostUSD,
+			Percentage: (us.CostUSD / totalCost) * 100,
+		})
+	}
+
+	// Feedback on this month's responses
+	feedbackByModel, err := r.PGStore.TenantStore().GetFeedbackStats(ctx, startOfMonth, now, false, derefStr(projectID))
+	if err != nil {
+		log.Printf("Failed to get feedback stats by model: %v", err)
+	}
+	feedbackByRole, err := r.PGStore.TenantStore().GetFeedbackStats(ctx, startOfMonth, now, true, derefStr(projectID))
+	if err != nil {
+		log.Printf("Failed to get feedback stats by role: %v", err)
+	}
+
 	return &model.DashboardStats{
 		TotalRequests:     int(stats.TotalRequests),
 		TotalTokens:       int(stats.TotalTokens),
@@ -2610,6 +4994,9 @@ func (r *queryResolver) Dashboard(ctx context.Context) (*model.DashboardStats, e
 		TopModels:         topModels,
 		ProviderBreakdown: providerBreakdown,
 		APIKeyBreakdown:   apiKeyBreakdown,
+		UserBreakdown:     userBreakdown,
+		FeedbackByModel:   convertFeedbackStatsToModel(feedbackByModel),
+		FeedbackByRole:    convertFeedbackStatsToModel(feedbackByRole),
 	}, nil
 }
 
@@ -2622,7 +5009,7 @@ func (r *queryResolver) RequestLogs(ctx context.Context, filter *model.RequestLo
 	}
 
 	// Parse filter parameters
-	var modelFilter, statusFilter, apiKeyIDFilter string
+	var modelFilter, statusFilter, apiKeyIDFilter, projectIDFilter string
 	startTime := time.Now().AddDate(0, 0, -30) // Default to last 30 days
 	endTime := time.Now()
 
@@ -2636,6 +5023,9 @@ func (r *queryResolver) RequestLogs(ctx context.Context, filter *model.RequestLo
 		if filter.APIKeyID != nil {
 			apiKeyIDFilter = *filter.APIKeyID
 		}
+		if filter.ProjectID != nil {
+			projectIDFilter = *filter.ProjectID
+		}
 		if filter.StartDate != nil {
 			startTime = *filter.StartDate
 		}
@@ -2644,8 +5034,14 @@ func (r *queryResolver) RequestLogs(ctx context.Context, filter *model.RequestLo
 		}
 	}
 
+	// Users with only usage:read_own see the requests of their personal keys
+	var userIDFilter string
+	if OwnRecordsOnly(ctx) {
+		userIDFilter = GetUserFromContext(ctx)
+	}
+
 	// Query database
-	records, err := r.PGStore.ListUsageRecords(ctx, startTime, endTime, modelFilter, statusFilter, apiKeyIDFilter, limit)
+	records, err := r.PGStore.ListUsageRecords(ctx, startTime, endTime, modelFilter, statusFilter, apiKeyIDFilter, projectIDFilter, userIDFilter, limit)
 	if err != nil {
 		log.Printf("Failed to list usage records: %v", err)
 		return &model.RequestLogConnection{
@@ -2693,7 +5089,7 @@ func (r *queryResolver) RequestLogs(ctx context.Context, filter *model.RequestLo
 			errorMessage = &record.ErrorMessage
 		}
 
-		edges = append(edges, model.RequestLog{
+		entry := model.RequestLog{
 			ID:           record.ID,
 			Model:        record.Model,
 			Provider:     providerEnum,
@@ -2706,7 +5102,11 @@ func (r *queryResolver) RequestLogs(ctx context.Context, filter *model.RequestLo
 			ErrorCode:    errorCode,
 			ErrorMessage: errorMessage,
 			CreatedAt:    record.Timestamp,
-		})
+
+			CancelledByClient: record.CancelledByClient,
+		}
+		entry.TimeToFirstTokenMs, entry.TokensPerSecond = streamTimingToModel(record)
+		edges = append(edges, entry)
 	}
 
 	return &model.RequestLogConnection{
@@ -2723,6 +5123,18 @@ func (r *queryResolver) RequestLog(ctx context.Context, id string) (*model.Reque
 	if err != nil {
 		return nil, fmt.Errorf("request log not found: %w", err)
 	}
+	if record == nil || (OwnRecordsOnly(ctx) && record.UserID != GetUserFromContext(ctx)) {
+		return nil, nil
+	}
+
+	// Captured content encrypted at rest is only decrypted for admins
+	if IsAdminFromContext(ctx) {
+		if err := r.PGStore.OpenCapturedContent(ctx, record); err != nil {
+			return nil, fmt.Errorf("failed to decrypt request log: %w", err)
+		}
+	} else {
+		redactCapturedContent(record.Metadata)
+	}
 
 	// Map provider string to enum
 	var providerEnum model.Provider
@@ -2748,8 +5160,11 @@ func (r *queryResolver) RequestLog(ctx context.Context, id string) (*model.Reque
 	}
 
 	// Extract prompt and response from metadata
-	var prompt, response *string
+	var prompt, response, replayOf *string
 	if record.Metadata != nil {
+		if id, ok := record.Metadata["replay_of"].(string); ok && id != "" {
+			replayOf = &id
+		}
 		if p, ok := record.Metadata["prompt"].(string); ok && p != "" {
 			prompt = &p
 		}
@@ -2770,6 +5185,8 @@ func (r *queryResolver) RequestLog(ctx context.Context, id string) (*model.Reque
 		apiKeyName = &record.APIKeyName
 	}
 
+	ttftMs, tokensPerSecond := streamTimingToModel(record)
+
 	return &model.RequestLogDetail{
 		ID:           record.ID,
 		Model:        record.Model,
@@ -2786,11 +5203,17 @@ func (r *queryResolver) RequestLog(ctx context.Context, id string) (*model.Reque
 		Prompt:       prompt,
 		Response:     response,
 		Metadata:     record.Metadata,
+		Replayable:   record.Metadata["request"] != nil,
+		ReplayOf:     replayOf,
+
+		CancelledByClient:  record.CancelledByClient,
+		TimeToFirstTokenMs: ttftMs,
+		TokensPerSecond:    tokensPerSecond,
 	}, nil
 }
 
 // CostAnalysis is the resolver for the costAnalysis field.
-func (r *queryResolver) CostAnalysis(ctx context.Context, startDate *time.Time, endDate *time.Time) (*model.CostAnalysis, error) {
+func (r *queryResolver) CostAnalysis(ctx context.Context, startDate *time.Time, endDate *time.Time, projectID *string) (*model.CostAnalysis, error) {
 	// Set default date range to last month
 	start := time.Now().AddDate(0, -1, 0)
 	end := time.Now()
@@ -2802,14 +5225,14 @@ func (r *queryResolver) CostAnalysis(ctx context.Context, startDate *time.Time,
 	}
 
 	// Get overall stats
-	stats, err := r.PGStore.GetUsageStats(ctx, start, end)
+	stats, err := r.PGStore.GetUsageStats(ctx, start, end, derefStr(projectID))
 	if err != nil {
 		log.Printf("Failed to get usage stats: %v", err)
 		stats = &domain.UsageStats{}
 	}
 
 	// Get daily time series
-	timeSeries, err := r.PGStore.GetUsageTimeSeries(ctx, start, end, "day")
+	timeSeries, err := r.PGStore.GetUsageTimeSeries(ctx, start, end, "day", derefStr(projectID))
 	if err != nil {
 		log.Printf("Failed to get time series: %v", err)
 		timeSeries = []*domain.UsageTimePoint{}
@@ -2825,7 +5248,7 @@ func (r *queryResolver) CostAnalysis(ctx context.Context, startDate *time.Time,
 	}
 
 	// Get provider breakdown
-	providerStats, err := r.PGStore.GetUsageStatsByProvider(ctx, start, end)
+	providerStats, err := r.PGStore.GetUsageStatsByProvider(ctx, start, end, derefStr(projectID))
 	if err != nil {
 		log.Printf("Failed to get provider stats: %v", err)
 		providerStats = make(map[string]*domain.ProviderUsageStats)
@@ -2862,7 +5285,7 @@ func (r *queryResolver) CostAnalysis(ctx context.Context, startDate *time.Time,
 	}
 
 	// Get model breakdown
-	modelStats, err := r.PGStore.GetUsageStatsByModel(ctx, start, end)
+	modelStats, err := r.PGStore.GetUsageStatsByModel(ctx, start, end, derefStr(projectID))
 	if err != nil {
 		log.Printf("Failed to get model stats: %v", err)
 		modelStats = make(map[string]*domain.ModelUsageStats)
@@ -2900,7 +5323,7 @@ func (r *queryResolver) CostAnalysis(ctx context.Context, startDate *time.Time,
 }
 
 // Performance is the resolver for the performance field.
-func (r *queryResolver) Performance(ctx context.Context, startDate *time.Time, endDate *time.Time) (*model.PerformanceMetrics, error) {
+func (r *queryResolver) Performance(ctx context.Context, startDate *time.Time, endDate *time.Time, projectID *string) (*model.PerformanceMetrics, error) {
 	// Set default date range to last 7 days
 	start := time.Now().AddDate(0, 0, -7)
 	end := time.Now()
@@ -2912,7 +5335,7 @@ func (r *queryResolver) Performance(ctx context.Context, startDate *time.Time, e
 	}
 
 	// Get provider stats for average latency
-	providerStats, err := r.PGStore.GetUsageStatsByProvider(ctx, start, end)
+	providerStats, err := r.PGStore.GetUsageStatsByProvider(ctx, start, end, derefStr(projectID))
 	if err != nil {
 		log.Printf("Failed to get provider stats: %v", err)
 		providerStats = make(map[string]*domain.ProviderUsageStats)
@@ -2934,27 +5357,25 @@ func (r *queryResolver) Performance(ctx context.Context, startDate *time.Time, e
 		}
 	}
 
-	// Get model stats for per-model performance
-	modelStats, err := r.PGStore.GetUsageStatsByModel(ctx, start, end)
+	// Per-model performance, including streaming latency
+	perf, err := r.PGStore.GetModelPerformance(ctx, start, end, derefStr(projectID))
 	if err != nil {
-		log.Printf("Failed to get model stats: %v", err)
-		modelStats = make(map[string]*domain.ModelUsageStats)
+		log.Printf("Failed to get model performance: %v", err)
 	}
 
-	modelPerformance := make([]model.ModelPerformance, 0, len(modelStats))
-	for modelID, mStats := range modelStats {
-		// Calculate success rate (TODO: need success/failure tracking in DB)
-		successRate := 99.0 // Default placeholder
-
-		// Use average latency from overall stats as estimate
-		// TODO: Track latency per model in database
-		modelAvgLatency := avgLatency
-
+	modelPerformance := make([]model.ModelPerformance, 0, len(perf))
+	for _, p := range perf {
 		modelPerformance = append(modelPerformance, model.ModelPerformance{
-			Model:        modelID,
-			AvgLatencyMs: modelAvgLatency,
-			SuccessRate:  successRate,
-			RequestCount: int(mStats.Requests),
+			Model:                 p.Model,
+			Provider:              model.Provider(strings.ToUpper(string(p.Provider))),
+			AvgLatencyMs:          p.AvgLatencyMs,
+			P95LatencyMs:          p.P95LatencyMs,
+			SuccessRate:           p.SuccessRate * 100,
+			RequestCount:          int(p.TotalRequests),
+			StreamedRequestCount:  int(p.StreamedRequests),
+			AvgTimeToFirstTokenMs: p.AvgTimeToFirstTokenMs,
+			P95TimeToFirstTokenMs: p.P95TimeToFirstTokenMs,
+			AvgTokensPerSecond:    p.TokensPerSecond,
 		})
 	}
 
@@ -2982,6 +5403,15 @@ func (r *queryResolver) AgentDashboard(ctx context.Context, apiKeyID string, sta
 	}
 
 	tenantStore := r.PGStore.TenantStore()
+	if OwnRecordsOnly(ctx) {
+		key, err := tenantStore.GetAPIKey(ctx, apiKeyID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get API key: %w", err)
+		}
+		if key == nil || key.OwnerUserID != GetUserFromContext(ctx) {
+			return nil, errors.New("API key not found")
+		}
+	}
 	dashboardStore := tenantStore.AgentDashboardStore()
 	if dashboardStore == nil {
 		return nil, fmt.Errorf("agent dashboard store not available")
@@ -2990,304 +5420,633 @@ func (r *queryResolver) AgentDashboard(ctx context.Context, apiKeyID string, sta
 	// Fetch all dashboard data (using "default" as tenant ID for single-tenant mode)
 	stats, err := dashboardStore.GetStats(ctx, "default", apiKeyID, startTime, endTime)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get agent dashboard stats: %w", err)
+		return nil, fmt.Errorf("failed to get agent dashboard stats: %w", err)
+	}
+
+	// Convert domain types to GraphQL model types
+	return convertAgentDashboardStats(stats), nil
+}
+
+// BudgetAlerts is the resolver for the budgetAlerts field.
+func (r *queryResolver) BudgetAlerts(ctx context.Context) ([]model.BudgetAlert, error) {
+	return []model.BudgetAlert{}, nil
+}
+
+// BudgetAlert is the resolver for the budgetAlert field.
+func (r *queryResolver) BudgetAlert(ctx context.Context, id string) (*model.BudgetAlert, error) {
+	return nil, fmt.Errorf("not found")
+}
+
+// QuotaLimits is the resolver for the quotaLimits field.
+func (r *queryResolver) QuotaLimits(ctx context.Context) (*model.QuotaLimits, error) {
+	if GetTenantFromContext(ctx) == "" {
+		return nil, fmt.Errorf("tenant not specified")
+	}
+	if r.quota == nil {
+		return nil, errors.New("quotas not configured")
+	}
+
+	limits, err := r.quota.Limits(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := &model.QuotaLimits{
+		RequestsLimit: int(limits.RequestsLimit),
+		TokensLimit:   int(limits.TokensLimit),
+		CostLimitUsd:  limits.CostLimitUSD,
+		UpdatedBy:     optionalStr(limits.UpdatedBy),
+	}
+	if !limits.UpdatedAt.IsZero() {
+		result.UpdatedAt = &limits.UpdatedAt
+	}
+	return result, nil
+}
+
+// CurrentQuotaPeriod is the resolver for the currentQuotaPeriod field.
+func (r *queryResolver) CurrentQuotaPeriod(ctx context.Context) (*model.QuotaPeriod, error) {
+	if GetTenantFromContext(ctx) == "" {
+		return nil, fmt.Errorf("tenant not specified")
+	}
+	if r.quota == nil {
+		return nil, errors.New("quotas not configured")
+	}
+
+	period, err := r.quota.Current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := convertQuotaPeriodToModel(period)
+	return &result, nil
+}
+
+// QuotaPeriods is the resolver for the quotaPeriods field.
+func (r *queryResolver) QuotaPeriods(ctx context.Context, limit *int) ([]model.QuotaPeriod, error) {
+	if GetTenantFromContext(ctx) == "" {
+		return nil, fmt.Errorf("tenant not specified")
+	}
+	if r.quota == nil {
+		return []model.QuotaPeriod{}, nil
+	}
+
+	n := 12
+	if limit != nil && *limit > 0 {
+		n = *limit
+	}
+	periods, err := r.quota.History(ctx, n)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]model.QuotaPeriod, len(periods))
+	for i, p := range periods {
+		result[i] = convertQuotaPeriodToModel(p)
+	}
+	return result, nil
+}
+
+// AuditLogs is the resolver for the auditLogs field.
+func (r *queryResolver) AuditLogs(ctx context.Context, filter *model.AuditLogFilter, limit *int, offset *int) (*model.AuditLogConnection, error) {
+	tenantSlug := GetTenantFromContext(ctx)
+	if tenantSlug == "" {
+		return nil, fmt.Errorf("tenant not specified")
+	}
+
+	tenantStore, err := r.PGStore.GetTenantStore(tenantSlug)
+	if err != nil {
+		return nil, fmt.Errorf("getting tenant store: %w", err)
+	}
+
+	domainFilter := convertAuditLogFilter(filter)
+	domainFilter.Limit = 100 // default
+
+	if limit != nil && *limit > 0 {
+		domainFilter.Limit = *limit
+	}
+	if offset != nil && *offset > 0 {
+		domainFilter.Offset = *offset
+	}
+
+	logs, err := tenantStore.ListAuditLogs(ctx, domainFilter)
+	if err != nil {
+		return nil, fmt.Errorf("listing audit logs: %w", err)
+	}
+
+	count, err := tenantStore.CountAuditLogs(ctx, domainFilter)
+	if err != nil {
+		return nil, fmt.Errorf("counting audit logs: %w", err)
+	}
+
+	items := make([]model.AuditLog, len(logs))
+	for i, log := range logs {
+		items[i] = convertDomainAuditLogToModel(log)
+	}
+
+	return &model.AuditLogConnection{
+		Items:      items,
+		TotalCount: count,
+		HasMore:    domainFilter.Offset+len(logs) < count,
+	}, nil
+}
+
+// AuditLog is the resolver for the auditLog field.
+func (r *queryResolver) AuditLog(ctx context.Context, id string) (*model.AuditLog, error) {
+	tenantSlug := GetTenantFromContext(ctx)
+	if tenantSlug == "" {
+		return nil, fmt.Errorf("tenant not specified")
+	}
+
+	tenantStore, err := r.PGStore.GetTenantStore(tenantSlug)
+	if err != nil {
+		return nil, fmt.Errorf("getting tenant store: %w", err)
+	}
+
+	log, err := tenantStore.GetAuditLog(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("getting audit log: %w", err)
+	}
+	if log == nil {
+		return nil, nil
+	}
+
+	result := convertDomainAuditLogToModel(*log)
+	return &result, nil
+}
+
+// AuditLogExport is the resolver for the auditLogExport field.
+func (r *queryResolver) AuditLogExport(ctx context.Context, filter *model.AuditLogFilter, first *int, after *string) (*model.AuditLogPage, error) {
+	if GetTenantFromContext(ctx) == "" {
+		return nil, fmt.Errorf("tenant not specified")
+	}
+	if r.auditExport == nil {
+		return nil, errors.New("audit export not configured")
+	}
+
+	page, err := r.auditExport.Page(ctx, convertAuditLogFilter(filter), derefInt(first), derefStr(after))
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]model.AuditLog, len(page.Items))
+	for i, log := range page.Items {
+		items[i] = convertDomainAuditLogToModel(log)
+	}
+	return &model.AuditLogPage{
+		Items:     items,
+		EndCursor: optionalStr(page.EndCursor),
+		HasMore:   page.HasMore,
+	}, nil
+}
+
+// AuditExportJobs is the resolver for the auditExportJobs field.
+func (r *queryResolver) AuditExportJobs(ctx context.Context, limit *int) ([]model.AuditExportJob, error) {
+	if GetTenantFromContext(ctx) == "" {
+		return nil, fmt.Errorf("tenant not specified")
+	}
+	if r.auditExport == nil {
+		return []model.AuditExportJob{}, nil
+	}
+
+	n := 20
+	if limit != nil && *limit > 0 {
+		n = *limit
+	}
+	jobs, err := r.auditExport.Jobs(ctx, n)
+	if err != nil {
+		return nil, fmt.Errorf("listing audit export jobs: %w", err)
+	}
+	result := make([]model.AuditExportJob, len(jobs))
+	for i, job := range jobs {
+		result[i] = convertAuditExportJobToModel(job)
+	}
+	return result, nil
+}
+
+// AuditExportJob is the resolver for the auditExportJob field.
+func (r *queryResolver) AuditExportJob(ctx context.Context, id string) (*model.AuditExportJob, error) {
+	if GetTenantFromContext(ctx) == "" {
+		return nil, fmt.Errorf("tenant not specified")
+	}
+	if r.auditExport == nil {
+		return nil, nil
+	}
+
+	job, err := r.auditExport.Job(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("getting audit export job: %w", err)
+	}
+	if job == nil {
+		return nil, nil
+	}
+	result := convertAuditExportJobToModel(job)
+	return &result, nil
+}
+
+// RetentionPolicies is the resolver for the retentionPolicies field.
+func (r *queryResolver) RetentionPolicies(ctx context.Context) ([]model.RetentionPolicy, error) {
+	tenantSlug := GetTenantFromContext(ctx)
+	if tenantSlug == "" {
+		return nil, errors.New("tenant context required")
+	}
+
+	tenantStore, err := r.PGStore.GetTenantStore(tenantSlug)
+	if err != nil {
+		return nil, fmt.Errorf("getting tenant store: %w", err)
+	}
+	overrides, err := tenantStore.ListRetentionOverrides(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load retention policies: %w", err)
+	}
+
+	policies := retention.Policies(r.Config.Retention, overrides)
+	result := make([]model.RetentionPolicy, len(policies))
+	for i, p := range policies {
+		result[i] = *convertRetentionPolicyToModel(p)
+	}
+	return result, nil
+}
+
+// ConfigDocument is the resolver for the configDocument field.
+func (r *queryResolver) ConfigDocument(ctx context.Context) (string, error) {
+	tenantSlug := GetTenantFromContext(ctx)
+	if tenantSlug == "" {
+		return "", errors.New("tenant context required")
+	}
+	tenantStore, err := r.PGStore.GetTenantStore(tenantSlug)
+	if err != nil {
+		return "", fmt.Errorf("getting tenant store: %w", err)
+	}
+
+	doc, err := configsync.NewSyncer(tenantStore).Export(ctx)
+	if err != nil {
+		return "", err
+	}
+	data, err := doc.Marshal()
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// FeatureFlags is the resolver for the featureFlags field.
+func (r *queryResolver) FeatureFlags(ctx context.Context) ([]model.FeatureFlag, error) {
+	tenantSlug := GetTenantFromContext(ctx)
+	if tenantSlug == "" {
+		return nil, errors.New("tenant context required")
+	}
+	if r.featureFlags == nil {
+		return nil, errors.New("feature flags not configured")
+	}
+
+	tenantStore, err := r.PGStore.GetTenantStore(tenantSlug)
+	if err != nil {
+		return nil, fmt.Errorf("getting tenant store: %w", err)
+	}
+	flags, err := r.featureFlags.List(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	// Convert domain types to GraphQL model types
-	return convertAgentDashboardStats(stats), nil
+	roleNames := roleNamesByID(ctx, tenantStore)
+	result := make([]model.FeatureFlag, len(flags))
+	for i, f := range flags {
+		result[i] = *convertFeatureFlagToModel(f, roleNames)
+	}
+	return result, nil
 }
 
-// convertAgentDashboardStats converts domain.AgentDashboardStats to model.AgentDashboardStats
-func convertAgentDashboardStats(stats *domain.AgentDashboardStats) *model.AgentDashboardStats {
-	if stats == nil {
-		return nil
+// FeatureFlagChangelog is the resolver for the featureFlagChangelog field.
+func (r *queryResolver) FeatureFlagChangelog(ctx context.Context, filter *model.FeatureFlagChangelogFilter, limit *int) ([]model.FeatureFlagChange, error) {
+	if r.featureFlags == nil {
+		return nil, errors.New("feature flags not configured")
 	}
 
-	// Convert provider/model usage
-	providerModelUsage := make([]model.ProviderModelUsage, len(stats.ProviderUsage))
-	for i, usage := range stats.ProviderUsage {
-		providerModelUsage[i] = model.ProviderModelUsage{
-			Provider:     usage.Provider,
-			Model:        usage.Model,
-			RequestCount: int(usage.RequestCount),
-			TokenCount:   int(usage.TokenCount),
-			CostUsd:      usage.CostUSD,
-		}
+	domainFilter := domain.FeatureFlagChangeFilter{Limit: derefInt(limit)}
+	if filter != nil {
+		domainFilter.FlagKey = derefStr(filter.FlagKey)
+		domainFilter.StartTime = filter.StartDate
+		domainFilter.EndTime = filter.EndDate
+	}
+	changes, err := r.featureFlags.Changelog(ctx, domainFilter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load feature flag changelog: %w", err)
 	}
 
-	// Convert token metrics
-	tokenMetrics := convertTokenMetrics(&stats.TokenMetrics)
-
-	// Convert cache metrics
-	cacheMetrics := convertCacheMetrics(&stats.CacheStats)
-
-	// Convert tool call metrics
-	toolCallMetrics := convertToolCallMetrics(stats.ToolCallStats)
-
-	// Convert risk assessment
-	riskAssessment := convertRiskAssessment(&stats.RiskScore, stats.Violations)
-
-	return &model.AgentDashboardStats{
-		ProviderModelUsage: providerModelUsage,
-		TokenMetrics:       tokenMetrics,
-		CacheMetrics:       cacheMetrics,
-		ToolCallMetrics:    toolCallMetrics,
-		RiskAssessment:     riskAssessment,
+	result := make([]model.FeatureFlagChange, len(changes))
+	for i, c := range changes {
+		result[i] = convertFeatureFlagChangeToModel(c)
 	}
+	return result, nil
 }
 
-// convertTokenMetrics converts domain.TokenMetrics to model.TokenMetrics
-func convertTokenMetrics(metrics *domain.TokenMetrics) *model.TokenMetrics {
-	if metrics == nil {
-		return nil
+// KillSwitches is the resolver for the killSwitches field.
+func (r *queryResolver) KillSwitches(ctx context.Context) ([]model.KillSwitch, error) {
+	if r.killSwitch == nil {
+		return []model.KillSwitch{}, nil
 	}
 
-	// Convert ByModel map to slice
-	byModel := make([]model.ModelTokenBreakdown, 0, len(metrics.ByModel))
-	for modelName, breakdown := range metrics.ByModel {
-		byModel = append(byModel, model.ModelTokenBreakdown{
-			Model:          modelName,
-			InputTokens:    int(breakdown.InputTokens),
-			OutputTokens:   int(breakdown.OutputTokens),
-			ThinkingTokens: int(breakdown.ThinkingTokens),
-			CostUsd:        breakdown.CostUSD,
-		})
+	switches, err := r.killSwitch.List(ctx)
+	if err != nil {
+		return nil, err
 	}
-
-	return &model.TokenMetrics{
-		TotalInput:    int(metrics.TotalInput),
-		TotalOutput:   int(metrics.TotalOutput),
-		TotalThinking: int(metrics.TotalThinking),
-		TotalCost:     metrics.TotalCost,
-		ByModel:       byModel,
+	result := make([]model.KillSwitch, len(switches))
+	for i, ks := range switches {
+		result[i] = convertKillSwitchToModel(ks)
 	}
+	return result, nil
 }
 
-// convertCacheMetrics converts domain.CacheStatistics to model.AgentCacheMetrics
-func convertCacheMetrics(cache *domain.CacheStatistics) *model.AgentCacheMetrics {
-	if cache == nil {
-		return nil
+// Experiments is the resolver for the experiments field.
+func (r *queryResolver) Experiments(ctx context.Context) ([]model.Experiment, error) {
+	if r.experiments == nil {
+		return []model.Experiment{}, nil
 	}
 
-	return &model.AgentCacheMetrics{
-		TotalHits:   int(cache.TotalHits),
-		TotalMisses: int(cache.TotalMisses),
-		HitRate:     cache.HitRate,
-		TokensSaved: int(cache.TokensSaved),
-		CostSaved:   cache.CostSavedUSD,
+	list, err := r.experiments.List(ctx)
+	if err != nil {
+		return nil, err
 	}
+	result := make([]model.Experiment, len(list))
+	for i, e := range list {
+		result[i] = convertExperimentToModel(e)
+	}
+	return result, nil
 }
 
-// convertToolCallMetrics converts []domain.ToolCallStatistic to model.ToolCallMetrics
-func convertToolCallMetrics(toolCalls []domain.ToolCallStatistic) *model.ToolCallMetrics {
-	if len(toolCalls) == 0 {
-		return &model.ToolCallMetrics{
-			TotalCalls:   0,
-			SuccessCount: 0,
-			FailureCount: 0,
-			SuccessRate:  0,
-			ByTool:       []model.ToolCallBreakdown{},
-		}
+// Experiment is the resolver for the experiment field.
+func (r *queryResolver) Experiment(ctx context.Context, id string) (*model.Experiment, error) {
+	if r.experiments == nil {
+		return nil, nil
 	}
 
-	// Calculate totals
-	var totalCalls, successCount, failureCount int
-	byTool := make([]model.ToolCallBreakdown, len(toolCalls))
-
-	for i, stat := range toolCalls {
-		totalCalls += int(stat.TotalCount)
-		successCount += int(stat.SuccessCount)
-		failureCount += int(stat.FailureCount)
-
-		byTool[i] = model.ToolCallBreakdown{
-			ToolName:     stat.ToolName,
-			SuccessCount: int(stat.SuccessCount),
-			FailureCount: int(stat.FailureCount),
-			TotalCount:   int(stat.TotalCount),
-		}
+	e, err := r.experiments.Get(ctx, id)
+	if err != nil || e == nil {
+		return nil, err
 	}
+	result := convertExperimentToModel(e)
+	return &result, nil
+}
 
-	// Calculate success rate
-	var successRate float64
-	if totalCalls > 0 {
-		successRate = (float64(successCount) / float64(totalCalls)) * 100
+// ExperimentResults is the resolver for the experimentResults field.
+func (r *queryResolver) ExperimentResults(ctx context.Context, id string, from *time.Time, to *time.Time) (*model.ExperimentResults, error) {
+	if r.experiments == nil {
+		return nil, errors.New("experiments not configured")
 	}
 
-	return &model.ToolCallMetrics{
-		TotalCalls:   totalCalls,
-		SuccessCount: successCount,
-		FailureCount: failureCount,
-		SuccessRate:  successRate,
-		ByTool:       byTool,
+	var start, end time.Time
+	if from != nil {
+		start = *from
+	}
+	if to != nil {
+		end = *to
+	}
+	results, err := r.experiments.Results(ctx, id, start, end)
+	if err != nil {
+		return nil, err
 	}
+	return convertExperimentResultsToModel(results), nil
 }
 
-// convertRiskAssessment converts domain.RiskAssessment and violations to model.RiskAssessment
-func convertRiskAssessment(risk *domain.RiskAssessment, violations []domain.PolicyViolationStat) *model.RiskAssessment {
-	if risk == nil {
-		return nil
+// Feedback is the resolver for the feedback field.
+func (r *queryResolver) Feedback(ctx context.Context, filter *model.FeedbackFilter, limit *int) ([]model.Feedback, error) {
+	if r.feedback == nil {
+		return nil, errors.New("feedback not configured")
 	}
 
-	// Convert policy violation stats to summaries
-	policyViolations := make([]model.PolicyViolationSummary, len(violations))
-	for i, v := range violations {
-		policyViolations[i] = model.PolicyViolationSummary{
-			ViolationType: v.ViolationType,
-			Count:         int(v.Count),
-			AvgSeverity:   v.AvgSeverity,
+	f := domain.FeedbackFilter{Limit: 100}
+	if limit != nil && *limit > 0 {
+		f.Limit = *limit
+	}
+	if filter != nil {
+		if filter.From != nil {
+			f.From = *filter.From
 		}
+		if filter.To != nil {
+			f.To = *filter.To
+		}
+		f.Model = derefStr(filter.Model)
+		f.RoleID = derefStr(filter.RoleID)
+		if filter.Thumbs != nil {
+			up := *filter.Thumbs == model.ThumbsUp
+			f.ThumbsUp = &up
+		}
+	}
+	list, err := r.feedback.List(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]model.Feedback, len(list))
+	for i, fb := range list {
+		result[i] = convertFeedbackToModel(fb)
 	}
+	return result, nil
+}
 
-	// Generate recommendations based on risk level and violations
-	recommendations := generateRecommendations(risk, violations)
+// Embedders is the resolver for the embedders field.
+func (r *queryResolver) Embedders(ctx context.Context) ([]model.EmbedderSettings, error) {
+	if r.embedders == nil {
+		return []model.EmbedderSettings{}, nil
+	}
 
-	return &model.RiskAssessment{
-		OverallRiskScore: risk.Score,
-		RiskLevel:        risk.Level,
-		PolicyViolations: policyViolations,
-		RecentViolations: []model.PolicyViolationRecord{}, // Empty for now - can be populated later if needed
-		Recommendations:  recommendations,
+	list, err := r.embedders.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]model.EmbedderSettings, len(list))
+	for i, e := range list {
+		result[i] = convertEmbedderSettingsToModel(e)
 	}
+	return result, nil
 }
 
-// generateRecommendations generates security recommendations based on risk assessment
-func generateRecommendations(risk *domain.RiskAssessment, violations []domain.PolicyViolationStat) []string {
-	recommendations := []string{}
-
-	// Add recommendations based on risk level
-	if risk.Level == "high" || risk.Level == "critical" {
-		recommendations = append(recommendations, "Immediate review required: High risk detected")
+// DataPlaneAuditSettings is the resolver for the dataPlaneAuditSettings field.
+func (r *queryResolver) DataPlaneAuditSettings(ctx context.Context) (*model.DataPlaneAuditSettings, error) {
+	if r.dataAudit == nil {
+		return &model.DataPlaneAuditSettings{}, nil
 	}
+	result := convertDataPlaneAuditSettingsToModel(r.dataAudit.Settings(), r.dataAudit.Dropped())
+	return &result, nil
+}
 
-	// Add recommendations based on specific violations
-	violationTypes := make(map[string]bool)
-	for _, v := range violations {
-		violationTypes[v.ViolationType] = true
+// DataPlaneAuditEvents is the resolver for the dataPlaneAuditEvents field.
+func (r *queryResolver) DataPlaneAuditEvents(ctx context.Context, filter *model.DataPlaneAuditFilter, limit *int) ([]model.DataPlaneAuditEvent, error) {
+	if r.dataAudit == nil {
+		return []model.DataPlaneAuditEvent{}, nil
 	}
 
-	if violationTypes["prompt_injection"] {
-		recommendations = append(recommendations, "Consider implementing stricter prompt validation")
+	f := domain.DataPlaneAuditFilter{Limit: derefInt(limit)}
+	if filter != nil {
+		f.APIKeyID = derefStr(filter.APIKeyID)
+		f.Model = derefStr(filter.Model)
+		f.PolicyDecision = derefStr(filter.PolicyDecision)
+		f.From, f.To = filter.StartDate, filter.EndDate
+	}
+	events, err := r.dataAudit.List(ctx, f)
+	if err != nil {
+		return nil, err
 	}
-	if violationTypes["unauthorized_tool_access"] {
-		recommendations = append(recommendations, "Review and tighten tool access policies")
+	result := make([]model.DataPlaneAuditEvent, len(events))
+	for i, e := range events {
+		result[i] = convertDataPlaneAuditEventToModel(e)
 	}
-	if violationTypes["rate_limit_exceeded"] {
-		recommendations = append(recommendations, "Increase rate limits or investigate unusual activity")
+	return result, nil
+}
+
+// AttestationKeys is the resolver for the attestationKeys field.
+func (r *queryResolver) AttestationKeys(ctx context.Context) ([]model.AttestationKey, error) {
+	if r.attestation == nil {
+		return []model.AttestationKey{}, nil
 	}
-	if violationTypes["cost_limit_exceeded"] {
-		recommendations = append(recommendations, "Review budget allocation or optimize model usage")
+
+	keys, err := r.attestation.Keys(ctx)
+	if err != nil {
+		return nil, err
 	}
-	if violationTypes["content_filter"] {
-		recommendations = append(recommendations, "Review content filtering policies and training data")
+	result := make([]model.AttestationKey, len(keys))
+	for i, k := range keys {
+		result[i] = convertAttestationKeyToModel(k)
 	}
+	return result, nil
+}
 
-	// If no specific recommendations, add general one
-	if len(recommendations) == 0 && risk.Violations > 0 {
-		recommendations = append(recommendations, "Monitor policy violations and adjust settings as needed")
+// ModelDeprecations is the resolver for the modelDeprecations field.
+func (r *queryResolver) ModelDeprecations(ctx context.Context) ([]model.ModelDeprecation, error) {
+	if r.deprecations == nil {
+		return []model.ModelDeprecation{}, nil
 	}
 
-	return recommendations
+	list, err := r.deprecations.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]model.ModelDeprecation, len(list))
+	for i, d := range list {
+		result[i] = convertModelDeprecationToModel(d)
+	}
+	return result, nil
 }
 
-// BudgetAlerts is the resolver for the budgetAlerts field.
-func (r *queryResolver) BudgetAlerts(ctx context.Context) ([]model.BudgetAlert, error) {
-	return []model.BudgetAlert{}, nil
-}
+// CustomDomains is the resolver for the customDomains field.
+func (r *queryResolver) CustomDomains(ctx context.Context) ([]model.CustomDomain, error) {
+	if r.domains == nil {
+		return []model.CustomDomain{}, nil
+	}
 
-// BudgetAlert is the resolver for the budgetAlert field.
-func (r *queryResolver) BudgetAlert(ctx context.Context, id string) (*model.BudgetAlert, error) {
-	return nil, fmt.Errorf("not found")
+	list, err := r.domains.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]model.CustomDomain, len(list))
+	for i, d := range list {
+		result[i] = convertCustomDomainToModel(d)
+	}
+	return result, nil
 }
 
-// AuditLogs is the resolver for the auditLogs field.
-func (r *queryResolver) AuditLogs(ctx context.Context, filter *model.AuditLogFilter, limit *int, offset *int) (*model.AuditLogConnection, error) {
-	tenantSlug := GetTenantFromContext(ctx)
-	if tenantSlug == "" {
-		return nil, fmt.Errorf("tenant not specified")
+// InjectionPatterns is the resolver for the injectionPatterns field.
+func (r *queryResolver) InjectionPatterns(ctx context.Context) ([]model.InjectionPattern, error) {
+	if r.injection == nil {
+		return []model.InjectionPattern{}, nil
 	}
 
-	tenantStore, err := r.PGStore.GetTenantStore(tenantSlug)
+	patterns, err := r.injection.List(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("getting tenant store: %w", err)
+		return nil, err
 	}
+	result := make([]model.InjectionPattern, len(patterns))
+	for i, p := range patterns {
+		result[i] = convertInjectionPatternToModel(p)
+	}
+	return result, nil
+}
 
-	domainFilter := domain.AuditLogFilter{
-		Limit:  100, // default
-		Offset: 0,
+// TestInjectionDetection is the resolver for the testInjectionDetection field.
+func (r *queryResolver) TestInjectionDetection(ctx context.Context, text string) (*model.InjectionMatch, error) {
+	if r.injection == nil {
+		return nil, errors.New("injection detection not configured")
 	}
 
-	if filter != nil {
-		if filter.ResourceType != nil {
-			domainFilter.ResourceType = domain.AuditResourceType(strings.ToLower(string(*filter.ResourceType)))
-		}
-		if filter.ResourceID != nil {
-			domainFilter.ResourceID = *filter.ResourceID
-		}
-		if filter.Action != nil {
-			domainFilter.Action = domain.AuditAction(strings.ToLower(string(*filter.Action)))
-		}
-		if filter.ActorID != nil {
-			domainFilter.ActorID = *filter.ActorID
-		}
-		if filter.StartTime != nil {
-			domainFilter.StartTime = *filter.StartTime
-		}
-		if filter.EndTime != nil {
-			domainFilter.EndTime = *filter.EndTime
-		}
+	match, err := r.injection.Match(ctx, text)
+	if err != nil || match == nil {
+		return nil, err
 	}
+	pattern := convertInjectionPatternToModel(&match.Pattern)
+	return &model.InjectionMatch{
+		Pattern:    &pattern,
+		Similarity: match.Similarity,
+	}, nil
+}
 
-	if limit != nil && *limit > 0 {
-		domainFilter.Limit = *limit
+// ModelPrices is the resolver for the modelPrices field.
+func (r *queryResolver) ModelPrices(ctx context.Context, provider *model.Provider) ([]model.ModelPrice, error) {
+	if GetTenantFromContext(ctx) == "" {
+		return nil, errors.New("tenant context required")
 	}
-	if offset != nil && *offset > 0 {
-		domainFilter.Offset = *offset
+	if r.pricing == nil {
+		return nil, errors.New("pricing not configured")
 	}
 
-	logs, err := tenantStore.ListAuditLogs(ctx, domainFilter)
+	var domainProvider domain.Provider
+	if provider != nil {
+		domainProvider = domain.Provider(strings.ToLower(string(*provider)))
+	}
+	prices, err := r.pricing.Current(ctx, domainProvider)
 	if err != nil {
-		return nil, fmt.Errorf("listing audit logs: %w", err)
+		return nil, err
 	}
 
-	count, err := tenantStore.CountAuditLogs(ctx, domainFilter)
-	if err != nil {
-		return nil, fmt.Errorf("counting audit logs: %w", err)
+	result := make([]model.ModelPrice, 0, len(prices))
+	for _, p := range prices {
+		result = append(result, convertModelPriceToModel(p))
 	}
+	return result, nil
+}
 
-	items := make([]model.AuditLog, len(logs))
-	for i, log := range logs {
-		items[i] = convertDomainAuditLogToModel(log)
+// ModelPriceHistory is the resolver for the modelPriceHistory field.
+func (r *queryResolver) ModelPriceHistory(ctx context.Context, provider model.Provider, modelID string) ([]model.ModelPrice, error) {
+	if GetTenantFromContext(ctx) == "" {
+		return nil, errors.New("tenant context required")
+	}
+	if r.pricing == nil {
+		return nil, errors.New("pricing not configured")
 	}
 
-	return &model.AuditLogConnection{
-		Items:      items,
-		TotalCount: count,
-		HasMore:    domainFilter.Offset+len(logs) < count,
-	}, nil
+	prices, err := r.pricing.History(ctx, domain.Provider(strings.ToLower(string(provider))), modelID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]model.ModelPrice, 0, len(prices))
+	for _, p := range prices {
+		result = append(result, convertModelPriceToModel(p))
+	}
+	return result, nil
 }
 
-// AuditLog is the resolver for the auditLog field.
-func (r *queryResolver) AuditLog(ctx context.Context, id string) (*model.AuditLog, error) {
-	tenantSlug := GetTenantFromContext(ctx)
-	if tenantSlug == "" {
-		return nil, fmt.Errorf("tenant not specified")
+// UsageExportStatus is the resolver for the usageExportStatus field.
+func (r *queryResolver) UsageExportStatus(ctx context.Context) (*model.UsageExportStatus, error) {
+	if GetTenantFromContext(ctx) == "" {
+		return nil, errors.New("tenant context required")
 	}
 
-	tenantStore, err := r.PGStore.GetTenantStore(tenantSlug)
-	if err != nil {
-		return nil, fmt.Errorf("getting tenant store: %w", err)
+	cfg := r.Config.Export
+	status := &model.UsageExportStatus{
+		Enabled:     cfg.Enabled && r.usageExport != nil,
+		Format:      cfg.Format,
+		Partition:   cfg.Partition,
+		Destination: usageExportDestination(cfg.Destination),
+		Datasets:    []model.UsageExportDataset{},
+	}
+	if r.usageExport == nil {
+		return status, nil
 	}
 
-	log, err := tenantStore.GetAuditLog(ctx, id)
+	states, err := r.usageExport.States(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("getting audit log: %w", err)
+		return nil, err
 	}
-	if log == nil {
-		return nil, nil
+	for _, s := range states {
+		status.Datasets = append(status.Datasets, convertUsageExportStateToModel(s))
 	}
-
-	result := convertDomainAuditLogToModel(*log)
-	return &result, nil
+	return status, nil
 }
 
 // DiscoveredTools is the resolver for the discoveredTools field.
@@ -3530,6 +6289,26 @@ func (r *queryResolver) McpPermissions(ctx context.Context, roleID string) ([]mo
 	return result, nil
 }
 
+// McpToolApprovals is the resolver for the mcpToolApprovals field.
+func (r *queryResolver) McpToolApprovals(ctx context.Context, status *string) ([]model.MCPToolApproval, error) {
+	if r.toolApprovals == nil {
+		return []model.MCPToolApproval{}, nil
+	}
+	filter := ""
+	if status != nil {
+		filter = strings.ToLower(*status)
+	}
+	approvals, err := r.toolApprovals.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]model.MCPToolApproval, 0, len(approvals))
+	for _, a := range approvals {
+		result = append(result, *convertMCPToolApprovalToModel(a))
+	}
+	return result, nil
+}
+
 // McpToolExecutions returns MCP tool execution logs
 func (r *queryResolver) McpToolExecutions(ctx context.Context, limit *int, offset *int) ([]model.MCPToolExecution, error) {
 	tenantSlug := GetTenantFromContext(ctx)
@@ -3551,7 +6330,7 @@ func (r *queryResolver) McpToolExecutions(ctx context.Context, limit *int, offse
 		o = *offset
 	}
 
-	execs, _, err := store.ListMCPToolExecutions(ctx, l, o)
+	execs, _, err := store.ListMCPToolExecutions(ctx, domain.MCPToolExecutionFilter{}, l, o)
 	if err != nil {
 		return nil, err
 	}
@@ -3564,6 +6343,80 @@ func (r *queryResolver) McpToolExecutions(ctx context.Context, limit *int, offse
 	return result, nil
 }
 
+// McpToolExecutionLog is the resolver for the mcpToolExecutionLog field.
+func (r *queryResolver) McpToolExecutionLog(ctx context.Context, filter *model.MCPToolExecutionFilter, limit *int, offset *int) (*model.MCPToolExecutionConnection, error) {
+	tenantSlug := GetTenantFromContext(ctx)
+	if tenantSlug == "" {
+		return nil, fmt.Errorf("tenant not found in context")
+	}
+
+	store, err := r.PGStore.GetTenantStore(tenantSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	l := 50
+	o := 0
+	if limit != nil {
+		l = *limit
+	}
+	if offset != nil {
+		o = *offset
+	}
+
+	execs, total, err := store.ListMCPToolExecutions(ctx, convertMCPExecutionFilter(filter), l, o)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]model.MCPToolExecution, len(execs))
+	for i, e := range execs {
+		items[i] = domainToMCPToolExecutionModel(e)
+	}
+
+	return &model.MCPToolExecutionConnection{
+		Items:      items,
+		TotalCount: total,
+		HasMore:    o+len(items) < total,
+	}, nil
+}
+
+// McpToolAnalytics is the resolver for the mcpToolAnalytics field.
+func (r *queryResolver) McpToolAnalytics(ctx context.Context, filter *model.MCPToolExecutionFilter, interval *model.AnalyticsInterval) (*model.MCPToolAnalytics, error) {
+	tenantSlug := GetTenantFromContext(ctx)
+	if tenantSlug == "" {
+		return nil, fmt.Errorf("tenant not found in context")
+	}
+
+	store, err := r.PGStore.GetTenantStore(tenantSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	// Default to the last 7 days
+	domainFilter := convertMCPExecutionFilter(filter)
+	if domainFilter.EndTime.IsZero() {
+		domainFilter.EndTime = time.Now()
+	}
+	if domainFilter.StartTime.IsZero() {
+		domainFilter.StartTime = domainFilter.EndTime.AddDate(0, 0, -7)
+	}
+
+	bucket := "day"
+	if interval != nil {
+		bucket = strings.ToLower(string(*interval))
+	} else if domainFilter.EndTime.Sub(domainFilter.StartTime) <= 48*time.Hour {
+		bucket = "hour"
+	}
+
+	analytics, err := store.GetMCPToolAnalytics(ctx, domainFilter, bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	return domainToMCPToolAnalyticsModel(analytics, domainFilter.StartTime, domainFilter.EndTime), nil
+}
+
 // MCPServersWithTools returns all MCP servers with their tools and visibility for a role
 func (r *queryResolver) McpServersWithTools(ctx context.Context, roleID string) ([]model.MCPServerWithTools, error) {
 	tenantSlug := GetTenantFromContext(ctx)
@@ -3689,6 +6542,38 @@ func (r *queryResolver) CacheMetrics(ctx context.Context) (*model.CacheMetrics,
 	}, nil
 }
 
+// CacheEntries is the resolver for the cacheEntries field.
+func (r *queryResolver) CacheEntries(ctx context.Context, filter *model.CacheEntryFilter, limit *int, offset *int) (*model.CacheEntryConnection, error) {
+	if GetTenantFromContext(ctx) == "" {
+		return nil, errors.New("tenant context required")
+	}
+	if r.semanticCache == nil {
+		return nil, errors.New("semantic cache not configured")
+	}
+
+	pageSize, skip := 50, 0
+	if limit != nil && *limit > 0 {
+		pageSize = min(*limit, 500)
+	}
+	if offset != nil && *offset > 0 {
+		skip = *offset
+	}
+	entries, total, err := r.semanticCache.List(ctx, convertCacheEntryFilter(filter), pageSize, skip)
+	if err != nil {
+		return nil, fmt.Errorf("listing cache entries: %w", err)
+	}
+
+	items := make([]model.CacheEntry, len(entries))
+	for i, e := range entries {
+		items[i] = convertCacheEntryToModel(e)
+	}
+	return &model.CacheEntryConnection{
+		Items:      items,
+		TotalCount: int(total),
+		HasMore:    skip+len(entries) < int(total),
+	}, nil
+}
+
 // RoutingMetrics is the resolver for the routingMetrics field.
 func (r *queryResolver) RoutingMetrics(ctx context.Context) (*model.RoutingMetrics, error) {
 	// Single-tenant mode - use default tenant store
@@ -3797,6 +6682,44 @@ func (r *queryResolver) ProviderHealthMetrics(ctx context.Context) (*model.Provi
 	}, nil
 }
 
+// ProviderHealthTimeline is the resolver for the providerHealthTimeline field.
+func (r *queryResolver) ProviderHealthTimeline(ctx context.Context, provider string, modelID *string, from time.Time, to time.Time, stepSeconds *int) ([]model.ProviderHealthSample, error) {
+	if r.healthHistory == nil {
+		return nil, healthhistory.ErrDisabled
+	}
+
+	samples, err := r.healthHistory.Timeline(ctx, strings.ToLower(provider), derefStr(modelID), from, to, time.Duration(derefInt(stepSeconds))*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]model.ProviderHealthSample, len(samples))
+	for i := range samples {
+		result[i] = convertHealthSampleToModel(&samples[i])
+	}
+	return result, nil
+}
+
+// ProviderSLAReport is the resolver for the providerSLAReport field.
+func (r *queryResolver) ProviderSLAReport(ctx context.Context, month string) ([]model.ProviderSLAReport, error) {
+	if r.healthHistory == nil {
+		return nil, healthhistory.ErrDisabled
+	}
+	start, err := time.Parse("2006-01", month)
+	if err != nil {
+		return nil, fmt.Errorf("month must be YYYY-MM: %w", err)
+	}
+
+	reports, err := r.healthHistory.SLAReport(ctx, start)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]model.ProviderSLAReport, len(reports))
+	for i := range reports {
+		result[i] = convertSLAReportToModel(&reports[i])
+	}
+	return result, nil
+}
+
 // RequestLogAdded is the resolver for the requestLogAdded field.
 func (r *subscriptionResolver) RequestLogAdded(ctx context.Context) (<-chan *model.RequestLog, error) {
 	ch := make(chan *model.RequestLog)
@@ -3818,6 +6741,9 @@ func (r *subscriptionResolver) BudgetAlertTriggered(ctx context.Context, alertID
 	return ch, nil
 }
 
+// APIKey returns generated.APIKeyResolver implementation.
+func (r *Resolver) APIKey() generated.APIKeyResolver { return &aPIKeyResolver{r} }
+
 // Mutation returns generated.MutationResolver implementation.
 func (r *Resolver) Mutation() generated.MutationResolver { return &mutationResolver{r} }
 
@@ -3832,6 +6758,7 @@ func (r *Resolver) Query() generated.QueryResolver { return &queryResolver{r} }
 // Subscription returns generated.SubscriptionResolver implementation.
 func (r *Resolver) Subscription() generated.SubscriptionResolver { return &subscriptionResolver{r} }
 
+type aPIKeyResolver struct{ *Resolver }
 type mutationResolver struct{ *Resolver }
 type providerConfigResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
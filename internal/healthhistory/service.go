@@ -0,0 +1,168 @@
+// Package healthhistory keeps the provider health tracker's view over time.
+// Every bucket, each instance stores what it saw of each provider/model:
+// requests, failures the provider is answerable for, latency percentiles and
+// the circuit breaker state. The samples back a timeline per provider/model
+// and monthly SLA reports to take to vendors.
+package healthhistory
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"time"
+
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+)
+
+// cleanupInterval is how often expired samples are deleted
+const cleanupInterval = time.Hour
+
+// maxTimelinePoints bounds a timeline; longer ranges get wider buckets
+const maxTimelinePoints = 2000
+
+var (
+	ErrDisabled     = errors.New("provider health history is disabled")
+	ErrInvalidRange = errors.New("the end of the range must be after its start")
+	ErrFutureMonth  = errors.New("the month hasn't started")
+)
+
+// Store is the persistence used by the service (implemented by postgres.TenantStore)
+type Store interface {
+	InsertProviderHealthSamples(ctx context.Context, samples []domain.ProviderHealthSample) error
+	ListProviderHealthSamples(ctx context.Context, provider, model string, from, to time.Time, step int) ([]domain.ProviderHealthSample, error)
+	ListProviderSLAReports(ctx context.Context, from, to time.Time, degradedBelow float64) ([]domain.ProviderSLAReport, error)
+	DeleteProviderHealthSamples(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+// Source produces the samples (implemented by health.Tracker)
+type Source interface {
+	EnableHistory()
+	TakeHistory(bucketStart time.Time, bucket time.Duration) []domain.ProviderHealthSample
+}
+
+// Service writes health samples and reports on them
+type Service struct {
+	cfg    config.HealthHistoryConfig
+	store  Store
+	source Source
+	now    func() time.Time
+}
+
+// NewService creates a health history service
+func NewService(cfg config.HealthHistoryConfig, store Store, source Source) *Service {
+	return &Service{cfg: cfg, store: store, source: source, now: time.Now}
+}
+
+// Enabled reports whether samples are being kept
+func (s *Service) Enabled() bool {
+	return s != nil && s.cfg.Enabled
+}
+
+// Start stores a sample of every provider/model at the end of each bucket and
+// deletes expired samples, until ctx is done
+func (s *Service) Start(ctx context.Context) {
+	if !s.cfg.Enabled {
+		return
+	}
+	s.source.EnableHistory()
+	slog.Info("Provider health history enabled", "bucket", s.cfg.Bucket, "retention", s.cfg.Retention)
+
+	go func() {
+		bucketStart := s.now().Truncate(s.cfg.Bucket)
+		next := time.NewTimer(time.Until(bucketStart.Add(s.cfg.Bucket)))
+		defer next.Stop()
+		cleanup := time.NewTicker(cleanupInterval)
+		defer cleanup.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				s.flush(context.WithoutCancel(ctx), bucketStart)
+				return
+			case <-next.C:
+				s.flush(ctx, bucketStart)
+				bucketStart = s.now().Truncate(s.cfg.Bucket)
+				next.Reset(time.Until(bucketStart.Add(s.cfg.Bucket)))
+			case <-cleanup.C:
+				s.cleanup(ctx)
+			}
+		}
+	}()
+}
+
+func (s *Service) flush(ctx context.Context, bucketStart time.Time) {
+	samples := s.source.TakeHistory(bucketStart.UTC(), s.cfg.Bucket)
+	if len(samples) == 0 {
+		return
+	}
+	if err := s.store.InsertProviderHealthSamples(ctx, samples); err != nil {
+		slog.Warn("Failed to store provider health samples", "bucket_start", bucketStart, "error", err)
+	}
+}
+
+func (s *Service) cleanup(ctx context.Context) {
+	if s.cfg.Retention <= 0 {
+		return
+	}
+	deleted, err := s.store.DeleteProviderHealthSamples(ctx, s.now().Add(-s.cfg.Retention))
+	if err != nil {
+		slog.Warn("Failed to delete expired provider health samples", "error", err)
+	} else if deleted > 0 {
+		slog.Debug("Deleted expired provider health samples", "count", deleted)
+	}
+}
+
+// Timeline returns a provider/model's samples in [from, to), oldest first, in
+// buckets of step. The step is rounded up to a multiple of the bucket, and
+// widened so the timeline has at most maxTimelinePoints. An empty model
+// selects the provider as a whole.
+func (s *Service) Timeline(ctx context.Context, provider, model string, from, to time.Time, step time.Duration) ([]domain.ProviderHealthSample, error) {
+	if !s.Enabled() {
+		return nil, ErrDisabled
+	}
+	if !to.After(from) {
+		return nil, ErrInvalidRange
+	}
+	step = max(step, to.Sub(from)/maxTimelinePoints)
+	bucket := s.cfg.Bucket
+	step = max(bucket, (step+bucket-1)/bucket*bucket)
+	return s.store.ListProviderHealthSamples(ctx, provider, model, from, to, int(step.Seconds()))
+}
+
+// SLAReport returns the service level of each provider and provider/model in
+// the calendar month (UTC) holding month. For the current month, it covers
+// the month so far.
+func (s *Service) SLAReport(ctx context.Context, month time.Time) ([]domain.ProviderSLAReport, error) {
+	if !s.Enabled() {
+		return nil, ErrDisabled
+	}
+	month = month.UTC()
+	start := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+	now := s.now()
+	if !now.After(start) {
+		return nil, ErrFutureMonth
+	}
+
+	reports, err := s.store.ListProviderSLAReports(ctx, start, end, s.cfg.DegradedBelow)
+	if err != nil {
+		return nil, err
+	}
+	minutes := end.Sub(start).Minutes()
+	if now.Before(end) {
+		minutes = now.Sub(start).Minutes()
+	}
+	for i := range reports {
+		r := &reports[i]
+		r.TargetPercent = s.cfg.SLATarget
+		r.AvailabilityPercent = 100
+		if r.Requests > 0 {
+			r.AvailabilityPercent = 100 * float64(r.Requests-r.Failures) / float64(r.Requests)
+		}
+		r.UptimePercent = 100 * (1 - math.Min(r.DowntimeMinutes, minutes)/minutes)
+		r.TargetMet = r.AvailabilityPercent >= r.TargetPercent && r.UptimePercent >= r.TargetPercent
+	}
+	return reports, nil
+}
@@ -0,0 +1,155 @@
+package healthhistory
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+	"modelgate/internal/routing/health"
+)
+
+type fakeStore struct {
+	samples []domain.ProviderHealthSample
+	step    int
+	reports []domain.ProviderSLAReport
+}
+
+func (f *fakeStore) InsertProviderHealthSamples(ctx context.Context, samples []domain.ProviderHealthSample) error {
+	f.samples = append(f.samples, samples...)
+	return nil
+}
+
+func (f *fakeStore) ListProviderHealthSamples(ctx context.Context, provider, model string, from, to time.Time, step int) ([]domain.ProviderHealthSample, error) {
+	f.step = step
+	return nil, nil
+}
+
+func (f *fakeStore) ListProviderSLAReports(ctx context.Context, from, to time.Time, degradedBelow float64) ([]domain.ProviderSLAReport, error) {
+	reports := make([]domain.ProviderSLAReport, len(f.reports))
+	copy(reports, f.reports)
+	return reports, nil
+}
+
+func (f *fakeStore) DeleteProviderHealthSamples(ctx context.Context, cutoff time.Time) (int64, error) {
+	return 0, nil
+}
+
+func testConfig() config.HealthHistoryConfig {
+	return config.Default().HealthHistory
+}
+
+func TestFlushSamplesTrackerOutcomes(t *testing.T) {
+	ctx := context.Background()
+	tracker := health.NewTracker(nil)
+	store := &fakeStore{}
+	s := NewService(testConfig(), store, tracker)
+
+	tracker.RecordSuccess(ctx, "", "openai", "openai/gpt-4o", 100) // Before history is enabled
+	tracker.EnableHistory()
+	for _, ms := range []int{100, 200, 300, 400} {
+		tracker.RecordSuccess(ctx, "", "openai", "openai/gpt-4o", ms)
+	}
+	tracker.RecordFailure(ctx, "", "openai", "gpt-4o", domain.ErrorCodeTimeout)
+	tracker.RecordFailure(ctx, "", "openai", "gpt-4o", domain.ErrorCodeContextLength)
+	tracker.RecordSuccess(ctx, "", "openai", "gpt-4o-mini", 50)
+	tracker.RecordBreakerState("anthropic", "open")
+
+	bucket := time.Date(2026, 9, 1, 10, 5, 0, 0, time.UTC)
+	s.flush(ctx, bucket)
+	if len(store.samples) != 4 {
+		t.Fatalf("expected samples for 2 models and 2 providers, got %+v", store.samples)
+	}
+	byKey := map[string]domain.ProviderHealthSample{}
+	for _, h := range store.samples {
+		if !h.BucketStart.Equal(bucket) || h.BucketSeconds != 300 {
+			t.Fatalf("sample stamped %v/%d", h.BucketStart, h.BucketSeconds)
+		}
+		byKey[h.Provider+"/"+h.Model] = h
+	}
+
+	gpt := byKey["openai/gpt-4o"]
+	if gpt.Requests != 5 || gpt.Failures != 1 || gpt.ClientErrors != 1 || gpt.P50LatencyMs != 200 || gpt.P99LatencyMs != 400 {
+		t.Fatalf("unexpected model sample %+v", gpt)
+	}
+	if openai := byKey["openai/"]; openai.Requests != 6 || openai.Failures != 1 || openai.BreakerState != "closed" {
+		t.Fatalf("unexpected provider sample %+v", openai)
+	}
+	// A provider without traffic is sampled for its breaker
+	if anthropic := byKey["anthropic/"]; anthropic.Requests != 0 || anthropic.BreakerState != "open" || anthropic.BreakerOpenSeconds < 0 {
+		t.Fatalf("unexpected breaker sample %+v", anthropic)
+	}
+
+	// The next bucket starts over; the still open breaker is sampled again
+	store.samples = nil
+	s.flush(ctx, bucket.Add(5*time.Minute))
+	if len(store.samples) != 1 || store.samples[0].Provider != "anthropic" || store.samples[0].BreakerState != "open" {
+		t.Fatalf("expected only the open breaker in the next bucket, got %+v", store.samples)
+	}
+}
+
+func TestTimelineStep(t *testing.T) {
+	store := &fakeStore{}
+	s := NewService(testConfig(), store, health.NewTracker(nil))
+	from := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		to   time.Time
+		step time.Duration
+		want int
+	}{
+		{from.Add(time.Hour), 0, 300},                     // The bucket
+		{from.Add(time.Hour), 7 * time.Minute, 600},       // Rounded up to buckets
+		{from.AddDate(0, 1, 0), 5 * time.Minute, 1500},    // At most maxTimelinePoints
+		{from.Add(24 * time.Hour), 24 * time.Hour, 86400}, // Wider is fine
+	}
+	for _, tt := range tests {
+		if _, err := s.Timeline(context.Background(), "openai", "", from, tt.to, tt.step); err != nil {
+			t.Fatal(err)
+		}
+		if store.step != tt.want {
+			t.Errorf("step %v over %v: got %ds, want %ds", tt.step, tt.to.Sub(from), store.step, tt.want)
+		}
+	}
+	if _, err := s.Timeline(context.Background(), "openai", "", from, from, 0); err != ErrInvalidRange {
+		t.Fatalf("expected an empty range to be refused, got %v", err)
+	}
+}
+
+func TestSLAReport(t *testing.T) {
+	store := &fakeStore{reports: []domain.ProviderSLAReport{
+		{Provider: "openai", Requests: 100000, Failures: 50, DowntimeMinutes: 30},
+		{Provider: "openai", Model: "gpt-4o", Requests: 1000, Failures: 5},
+		{Provider: "gemini"}, // Only breaker samples
+	}}
+	s := NewService(testConfig(), store, health.NewTracker(nil))
+	s.now = func() time.Time { return time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC) }
+
+	reports, err := s.SLAReport(context.Background(), time.Date(2026, 9, 15, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// September has 43200 minutes; 30 down is 99.93% uptime
+	near := func(got, want float64) bool { return math.Abs(got-want) < 0.001 }
+	if r := reports[0]; !near(r.AvailabilityPercent, 99.95) || !near(r.UptimePercent, 99.93) || !r.TargetMet || r.TargetPercent != 99.9 {
+		t.Fatalf("unexpected provider report %+v", r)
+	}
+	if r := reports[1]; !near(r.AvailabilityPercent, 99.5) || r.UptimePercent != 100 || r.TargetMet {
+		t.Fatalf("expected the model to miss the target, got %+v", r)
+	}
+	if r := reports[2]; r.AvailabilityPercent != 100 || !r.TargetMet {
+		t.Fatalf("expected a provider without requests to be available, got %+v", r)
+	}
+
+	// The month so far: 17 days
+	store.reports = []domain.ProviderSLAReport{{Provider: "openai", DowntimeMinutes: 17 * 24 * 1.5}}
+	reports, _ = s.SLAReport(context.Background(), time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC))
+	if !near(reports[0].UptimePercent, 97.5) {
+		t.Fatalf("expected the current month to cover the days so far, got %v", reports[0].UptimePercent)
+	}
+	if _, err := s.SLAReport(context.Background(), time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)); err != ErrFutureMonth {
+		t.Fatalf("expected a future month to be refused, got %v", err)
+	}
+}
@@ -0,0 +1,721 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"modelgate/internal/assistants"
+	"modelgate/internal/domain"
+	"modelgate/internal/gateway"
+	"modelgate/internal/mcp"
+	"modelgate/internal/policy"
+	"modelgate/internal/threads"
+)
+
+// AssistantRequest is the body for POST /v1/assistants and POST /v1/assistants/{assistant_id}.
+// When modifying, omitted fields are left as they are.
+type AssistantRequest struct {
+	Model        *string                 `json:"model"`
+	Name         *string                 `json:"name,omitempty"`
+	Description  *string                 `json:"description,omitempty"`
+	Instructions *string                 `json:"instructions,omitempty"`
+	Tools        *[]domain.AssistantTool `json:"tools,omitempty"` // "function", or "mcp" for the gateway's MCP tools
+	Temperature  *float32                `json:"temperature,omitempty"`
+	Metadata     map[string]string       `json:"metadata,omitempty"`
+}
+
+// AssistantResponse is the assistant object
+type AssistantResponse struct {
+	ID           string                 `json:"id"`
+	Object       string                 `json:"object"` // "assistant"
+	CreatedAt    int64                  `json:"created_at"`
+	Name         *string                `json:"name"`
+	Description  *string                `json:"description"`
+	Model        string                 `json:"model"`
+	Instructions *string                `json:"instructions"`
+	Tools        []domain.AssistantTool `json:"tools"`
+	Temperature  *float32               `json:"temperature"`
+	Metadata     map[string]string      `json:"metadata"`
+}
+
+// AssistantMessageRequest is a message in the Assistants API form
+type AssistantMessageRequest struct {
+	Role    string      `json:"role"`    // "user" or "assistant"
+	Content interface{} `json:"content"` // string or []ContentPart
+}
+
+// CreateRunRequest is the body for POST /v1/threads/{thread_id}/runs
+type CreateRunRequest struct {
+	AssistantID            string                    `json:"assistant_id"`
+	Model                  string                    `json:"model,omitempty"`
+	Instructions           string                    `json:"instructions,omitempty"`
+	AdditionalInstructions string                    `json:"additional_instructions,omitempty"`
+	AdditionalMessages     []AssistantMessageRequest `json:"additional_messages,omitempty"`
+	Tools                  *[]domain.AssistantTool   `json:"tools,omitempty"`
+	Temperature            *float32                  `json:"temperature,omitempty"`
+	Metadata               map[string]string         `json:"metadata,omitempty"`
+	Stream                 bool                      `json:"stream,omitempty"`
+}
+
+// CreateThreadAndRunRequest is the body for POST /v1/threads/runs
+type CreateThreadAndRunRequest struct {
+	CreateRunRequest
+	Thread struct {
+		Messages []AssistantMessageRequest `json:"messages,omitempty"`
+		Metadata map[string]string         `json:"metadata,omitempty"`
+	} `json:"thread"`
+}
+
+// SubmitToolOutputsRequest is the body for POST /v1/threads/{thread_id}/runs/{run_id}/submit_tool_outputs
+type SubmitToolOutputsRequest struct {
+	ToolOutputs []struct {
+		ToolCallID string `json:"tool_call_id"`
+		Output     string `json:"output"`
+	} `json:"tool_outputs"`
+	Stream bool `json:"stream,omitempty"`
+}
+
+// RunResponse is the run object
+type RunResponse struct {
+	ID             string                 `json:"id"`
+	Object         string                 `json:"object"` // "thread.run"
+	CreatedAt      int64                  `json:"created_at"`
+	ThreadID       string                 `json:"thread_id"`
+	AssistantID    string                 `json:"assistant_id"`
+	Status         domain.RunStatus       `json:"status"`
+	RequiredAction *RunRequiredAction     `json:"required_action"`
+	LastError      *domain.RunError       `json:"last_error"`
+	ExpiresAt      *int64                 `json:"expires_at"`
+	StartedAt      *int64                 `json:"started_at"`
+	CancelledAt    *int64                 `json:"cancelled_at"`
+	FailedAt       *int64                 `json:"failed_at"`
+	CompletedAt    *int64                 `json:"completed_at"`
+	Model          string                 `json:"model"`
+	Instructions   string                 `json:"instructions"`
+	Tools          []domain.AssistantTool `json:"tools"`
+	Temperature    *float32               `json:"temperature"`
+	Metadata       map[string]string      `json:"metadata"`
+	Usage          *domain.RunUsage       `json:"usage"` // Set once the run has ended
+}
+
+// RunRequiredAction lists the function calls a run waits for
+type RunRequiredAction struct {
+	Type              string `json:"type"` // "submit_tool_outputs"
+	SubmitToolOutputs struct {
+		ToolCalls []ToolCall `json:"tool_calls"`
+	} `json:"submit_tool_outputs"`
+}
+
+// AssistantMessageResponse is a thread message in the Assistants API form
+type AssistantMessageResponse struct {
+	ID          string                 `json:"id"`
+	Object      string                 `json:"object"` // "thread.message"
+	CreatedAt   int64                  `json:"created_at"`
+	ThreadID    string                 `json:"thread_id"`
+	Status      string                 `json:"status"` // "completed"
+	Role        string                 `json:"role"`
+	Content     []AssistantContentPart `json:"content"`
+	AssistantID *string                `json:"assistant_id"`
+	RunID       *string                `json:"run_id"`
+	Attachments []any                  `json:"attachments"`
+	Metadata    map[string]string      `json:"metadata"`
+}
+
+// AssistantContentPart is a text or image part of an Assistants API message
+type AssistantContentPart struct {
+	Type     string         `json:"type"` // "text" or "image_url"
+	Text     *AssistantText `json:"text,omitempty"`
+	ImageURL *struct {
+		URL string `json:"url"`
+	} `json:"image_url,omitempty"`
+}
+
+// AssistantText is the text of a message part
+type AssistantText struct {
+	Value       string `json:"value"`
+	Annotations []any  `json:"annotations"`
+}
+
+// AssistantListResponse is a page of an Assistants API list
+type AssistantListResponse[T any] struct {
+	Object  string `json:"object"` // "list"
+	Data    []T    `json:"data"`
+	FirstID string `json:"first_id,omitempty"`
+	LastID  string `json:"last_id,omitempty"`
+	HasMore bool   `json:"has_more"`
+}
+
+// SetAssistants enables the Assistants API endpoints (they also need threads)
+func (s *Server) SetAssistants(svc *assistants.Service) {
+	s.assistants = svc
+	s.mux = http.NewServeMux()
+	s.setupRoutes()
+}
+
+// assistantsAPI reports whether a request comes from an Assistants API
+// client, which gets thread messages in that API's form
+func assistantsAPI(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("OpenAI-Beta"), "assistants")
+}
+
+// handleCreateAssistant handles POST /v1/assistants
+func (s *Server) handleCreateAssistant(w http.ResponseWriter, r *http.Request, auth *AuthContext) {
+	var req AssistantRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON body")
+		return
+	}
+	a := domain.Assistant{Metadata: req.Metadata}
+	applyAssistantRequest(&a, &req)
+
+	created, err := s.assistants.CreateAssistant(r.Context(), authAPIKeyID(auth), a)
+	if err != nil {
+		s.writeAssistantError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, toAssistantResponse(created))
+}
+
+// handleListAssistants handles GET /v1/assistants
+func (s *Server) handleListAssistants(w http.ResponseWriter, r *http.Request, auth *AuthContext) {
+	limit := listLimit(r)
+	list, err := s.assistants.ListAssistants(r.Context(), authAPIKeyID(auth), limit)
+	if err != nil {
+		s.writeAssistantError(w, err)
+		return
+	}
+	resp := AssistantListResponse[AssistantResponse]{Object: "list", Data: make([]AssistantResponse, 0, len(list))}
+	for _, a := range list {
+		resp.Data = append(resp.Data, toAssistantResponse(a))
+	}
+	if len(resp.Data) > 0 {
+		resp.FirstID, resp.LastID = resp.Data[0].ID, resp.Data[len(resp.Data)-1].ID
+		resp.HasMore = len(resp.Data) == limit
+	}
+	s.writeJSON(w, http.StatusOK, resp)
+}
+
+// handleGetAssistant handles GET /v1/assistants/{assistant_id}
+func (s *Server) handleGetAssistant(w http.ResponseWriter, r *http.Request, auth *AuthContext) {
+	a, err := s.assistants.GetAssistant(r.Context(), r.PathValue("assistant_id"), authAPIKeyID(auth))
+	if err != nil {
+		s.writeAssistantError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, toAssistantResponse(a))
+}
+
+// handleModifyAssistant handles POST /v1/assistants/{assistant_id}
+func (s *Server) handleModifyAssistant(w http.ResponseWriter, r *http.Request, auth *AuthContext) {
+	var req AssistantRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON body")
+		return
+	}
+	a, err := s.assistants.GetAssistant(r.Context(), r.PathValue("assistant_id"), authAPIKeyID(auth))
+	if err != nil {
+		s.writeAssistantError(w, err)
+		return
+	}
+	applyAssistantRequest(a, &req)
+	if req.Metadata != nil {
+		a.Metadata = req.Metadata
+	}
+	if err := s.assistants.UpdateAssistant(r.Context(), a); err != nil {
+		s.writeAssistantError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, toAssistantResponse(a))
+}
+
+// handleDeleteAssistant handles DELETE /v1/assistants/{assistant_id}
+func (s *Server) handleDeleteAssistant(w http.ResponseWriter, r *http.Request, auth *AuthContext) {
+	id := r.PathValue("assistant_id")
+	if err := s.assistants.DeleteAssistant(r.Context(), id, authAPIKeyID(auth)); err != nil {
+		s.writeAssistantError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, map[string]any{
+		"id":      id,
+		"object":  "assistant.deleted",
+		"deleted": true,
+	})
+}
+
+// handleCreateRun handles POST /v1/threads/{thread_id}/runs
+func (s *Server) handleCreateRun(w http.ResponseWriter, r *http.Request, auth *AuthContext) {
+	var req CreateRunRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON body")
+		return
+	}
+	threadID := r.PathValue("thread_id")
+	if len(req.AdditionalMessages) > 0 {
+		msgs, ok := s.assistantMessages(w, req.AdditionalMessages)
+		if !ok {
+			return
+		}
+		if _, err := s.threadService.Append(r.Context(), threadID, authAPIKeyID(auth), msgs); err != nil {
+			s.writeAssistantError(w, err)
+			return
+		}
+	}
+	s.startRun(w, r, auth, threadID, &req)
+}
+
+// handleCreateThreadAndRun handles POST /v1/threads/runs
+func (s *Server) handleCreateThreadAndRun(w http.ResponseWriter, r *http.Request, auth *AuthContext) {
+	var req CreateThreadAndRunRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON body")
+		return
+	}
+	if req.Stream {
+		s.writeError(w, http.StatusBadRequest, "invalid_request", "Streaming runs are not supported; poll the run instead")
+		return
+	}
+	msgs, ok := s.assistantMessages(w, req.Thread.Messages)
+	if !ok {
+		return
+	}
+	thread, err := s.threadService.Create(r.Context(), authAPIKeyID(auth), msgs, req.Thread.Metadata)
+	if err != nil {
+		s.writeAssistantError(w, err)
+		return
+	}
+	s.startRun(w, r, auth, thread.ID, &req.CreateRunRequest)
+}
+
+func (s *Server) startRun(w http.ResponseWriter, r *http.Request, auth *AuthContext, threadID string, req *CreateRunRequest) {
+	if req.Stream {
+		s.writeError(w, http.StatusBadRequest, "invalid_request", "Streaming runs are not supported; poll the run instead")
+		return
+	}
+	params := assistants.RunParams{
+		ThreadID:               threadID,
+		AssistantID:            req.AssistantID,
+		Model:                  req.Model,
+		Instructions:           req.Instructions,
+		AdditionalInstructions: req.AdditionalInstructions,
+		Temperature:            req.Temperature,
+		Metadata:               req.Metadata,
+	}
+	if req.Tools != nil {
+		params.Tools = *req.Tools
+		if params.Tools == nil {
+			params.Tools = []domain.AssistantTool{}
+		}
+	}
+	run, err := s.assistants.CreateRun(r.Context(), authAPIKeyID(auth), params, s.runExecutor(r, auth))
+	if err != nil {
+		s.writeAssistantError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, toRunResponse(run))
+}
+
+// handleListRuns handles GET /v1/threads/{thread_id}/runs
+func (s *Server) handleListRuns(w http.ResponseWriter, r *http.Request, auth *AuthContext) {
+	limit := listLimit(r)
+	runs, err := s.assistants.ListRuns(r.Context(), r.PathValue("thread_id"), authAPIKeyID(auth), limit)
+	if err != nil {
+		s.writeAssistantError(w, err)
+		return
+	}
+	resp := AssistantListResponse[RunResponse]{Object: "list", Data: make([]RunResponse, 0, len(runs))}
+	for _, run := range runs {
+		resp.Data = append(resp.Data, toRunResponse(run))
+	}
+	if len(resp.Data) > 0 {
+		resp.FirstID, resp.LastID = resp.Data[0].ID, resp.Data[len(resp.Data)-1].ID
+		resp.HasMore = len(resp.Data) == limit
+	}
+	s.writeJSON(w, http.StatusOK, resp)
+}
+
+// handleGetRun handles GET /v1/threads/{thread_id}/runs/{run_id}
+func (s *Server) handleGetRun(w http.ResponseWriter, r *http.Request, auth *AuthContext) {
+	run, err := s.assistants.GetRun(r.Context(), r.PathValue("thread_id"), r.PathValue("run_id"), authAPIKeyID(auth))
+	if err != nil {
+		s.writeAssistantError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, toRunResponse(run))
+}
+
+// handleSubmitToolOutputs handles POST /v1/threads/{thread_id}/runs/{run_id}/submit_tool_outputs
+func (s *Server) handleSubmitToolOutputs(w http.ResponseWriter, r *http.Request, auth *AuthContext) {
+	var req SubmitToolOutputsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON body")
+		return
+	}
+	if req.Stream {
+		s.writeError(w, http.StatusBadRequest, "invalid_request", "Streaming runs are not supported; poll the run instead")
+		return
+	}
+	outputs := make([]assistants.ToolOutput, len(req.ToolOutputs))
+	for i, o := range req.ToolOutputs {
+		outputs[i] = assistants.ToolOutput{ToolCallID: o.ToolCallID, Output: o.Output}
+	}
+	run, err := s.assistants.SubmitToolOutputs(r.Context(), r.PathValue("thread_id"), r.PathValue("run_id"),
+		authAPIKeyID(auth), outputs, s.runExecutor(r, auth))
+	if err != nil {
+		s.writeAssistantError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, toRunResponse(run))
+}
+
+// handleCancelRun handles POST /v1/threads/{thread_id}/runs/{run_id}/cancel
+func (s *Server) handleCancelRun(w http.ResponseWriter, r *http.Request, auth *AuthContext) {
+	run, err := s.assistants.CancelRun(r.Context(), r.PathValue("thread_id"), r.PathValue("run_id"), authAPIKeyID(auth))
+	if err != nil {
+		s.writeAssistantError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, toRunResponse(run))
+}
+
+// assistantMessages converts Assistants API messages to domain messages. It
+// returns false after writing an error if a message cannot be stored.
+func (s *Server) assistantMessages(w http.ResponseWriter, msgs []AssistantMessageRequest) ([]domain.Message, bool) {
+	chat := make([]ChatMessage, len(msgs))
+	for i, m := range msgs {
+		if m.Role != "user" && m.Role != "assistant" {
+			s.writeError(w, http.StatusBadRequest, "invalid_request", fmt.Sprintf("Message %d has role %q; use user or assistant", i, m.Role))
+			return nil, false
+		}
+		chat[i] = ChatMessage{Role: m.Role, Content: m.Content}
+	}
+	return s.threadMessagesFromRequest(w, chat)
+}
+
+// writeAssistantMessages writes a page of thread messages in the Assistants
+// API form. Tool calls and their results are internal to runs and left out.
+func (s *Server) writeAssistantMessages(w http.ResponseWriter, r *http.Request, msgs []*domain.ThreadMessage) {
+	var data []AssistantMessageResponse
+	for _, m := range msgs {
+		if msg, ok := toAssistantMessage(m); ok {
+			data = append(data, msg)
+		}
+	}
+	if r.URL.Query().Get("order") != "asc" {
+		slices.Reverse(data)
+	}
+	if after := r.URL.Query().Get("after"); after != "" {
+		if i := slices.IndexFunc(data, func(m AssistantMessageResponse) bool { return m.ID == after }); i >= 0 {
+			data = data[i+1:]
+		}
+	}
+	if before := r.URL.Query().Get("before"); before != "" {
+		if i := slices.IndexFunc(data, func(m AssistantMessageResponse) bool { return m.ID == before }); i >= 0 {
+			data = data[:i]
+		}
+	}
+
+	resp := AssistantListResponse[AssistantMessageResponse]{Object: "list", Data: data}
+	if limit := listLimit(r); len(data) > limit {
+		resp.Data, resp.HasMore = data[:limit], true
+	}
+	if resp.Data == nil {
+		resp.Data = []AssistantMessageResponse{}
+	}
+	if len(resp.Data) > 0 {
+		resp.FirstID, resp.LastID = resp.Data[0].ID, resp.Data[len(resp.Data)-1].ID
+	}
+	s.writeJSON(w, http.StatusOK, resp)
+}
+
+// writeAssistantError maps assistants and threads service errors to HTTP responses
+func (s *Server) writeAssistantError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, assistants.ErrAssistantNotFound), errors.Is(err, assistants.ErrRunNotFound):
+		s.writeError(w, http.StatusNotFound, "not_found", err.Error())
+	case errors.Is(err, assistants.ErrInvalidRequest), errors.Is(err, assistants.ErrRunActive),
+		errors.Is(err, assistants.ErrRunState):
+		s.writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+	case errors.Is(err, threads.ErrThreadNotFound), errors.Is(err, threads.ErrThreadFull),
+		errors.Is(err, threads.ErrInvalidMessage):
+		s.writeThreadError(w, err)
+	default:
+		slog.Error("Assistants request failed", "error", err)
+		s.writeError(w, http.StatusInternalServerError, "server_error", "Internal server error")
+	}
+}
+
+// runExecutor makes a run's model and tool calls as the caller of r
+func (s *Server) runExecutor(r *http.Request, auth *AuthContext) *runExecutor {
+	e := &runExecutor{s: s, auth: auth}
+	if ip := clientIP(r, s.config.Load().Server.TrustedProxies); ip.IsValid() {
+		e.clientIP = ip.String()
+	}
+	return e
+}
+
+// runExecutor sends run model calls through the chat completions pipeline:
+// policies, the dispatcher queue and concurrency limits, then the gateway
+type runExecutor struct {
+	s        *Server
+	auth     *AuthContext
+	clientIP string
+}
+
+func (e *runExecutor) Complete(ctx context.Context, req *domain.ChatRequest) (*domain.ChatResponse, error) {
+	req.ClientIP = e.clientIP
+	if e.auth.APIKey != nil {
+		req.APIKeyID = e.auth.APIKey.ID
+		req.RoleID = e.auth.APIKey.RoleID
+		req.GroupID = e.auth.APIKey.GroupID
+		req.ProjectID = e.auth.APIKey.ProjectID
+	}
+	if _, err := e.s.enforcePoliciesForRequest(ctx, req, e.auth); err != nil {
+		code := "invalid_prompt"
+		var violation *policy.PolicyViolation
+		if errors.As(err, &violation) && (violation.Type == "rate_limit" || violation.Type == "budget") {
+			code = "rate_limit_exceeded"
+		}
+		return nil, &domain.RunError{Code: code, Message: err.Error()}
+	}
+	if e.s.dispatcher == nil {
+		return e.s.gateway.ChatComplete(ctx, req)
+	}
+
+	priority, concurrency, roleLimits := e.s.getConcurrencyForRequest(ctx, e.auth)
+	result, err := e.s.dispatcher.Submit(ctx, &gateway.DispatchRequest{
+		Ctx:                 ctx,
+		ChatReq:             req,
+		TenantSlug:          "default",
+		APIKeyID:            req.APIKeyID,
+		RoleID:              req.RoleID,
+		GroupID:             req.GroupID,
+		Priority:            priority,
+		Weight:              concurrency.Weight,
+		MaxConcurrentPerKey: int32(concurrency.MaxConcurrentPerKey),
+		RoleLimits:          roleLimits,
+		QueueWhenLimited:    concurrency.QueueWhenLimited,
+	})
+	if err != nil {
+		if errors.Is(err, gateway.ErrKeyLimited) || errors.Is(err, gateway.ErrRoleLimited) || errors.Is(err, gateway.ErrQueueFull) {
+			return nil, &domain.RunError{Code: "rate_limit_exceeded", Message: err.Error()}
+		}
+		return nil, err
+	}
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return result.Response, nil
+}
+
+func (e *runExecutor) mcpClient(ctx context.Context) (*mcp.MCPServer, *mcp.AuthenticatedClient) {
+	server, _ := e.s.mcpServer.(*mcp.MCPServer)
+	if e.auth.APIKey == nil || e.s.pgStore == nil {
+		return server, &mcp.AuthenticatedClient{TenantSlug: "default"}
+	}
+	return server, mcp.NewClient(ctx, e.s.pgStore.TenantStore(), "default", e.auth.APIKey)
+}
+
+func (e *runExecutor) MCPTools(ctx context.Context, serverName string) ([]domain.Tool, error) {
+	server, client := e.mcpClient(ctx)
+	if server == nil {
+		return nil, nil
+	}
+	list, err := server.ListTools(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	prefix := ""
+	if serverName != "" {
+		prefix = mcp.SanitizeServerName(serverName) + "__"
+	}
+	var tools []domain.Tool
+	for _, t := range list.Tools {
+		// tool_search returns schemas for the model to call directly, which a run can't offer
+		if t.Name == "tool_search" || t.Disabled || !strings.HasPrefix(t.Name, prefix) {
+			continue
+		}
+		tools = append(tools, domain.Tool{
+			Type:     "function",
+			Function: domain.FunctionDefinition{Name: t.Name, Description: t.Description, Parameters: t.InputSchema},
+		})
+	}
+	return tools, nil
+}
+
+func (e *runExecutor) CallMCPTool(ctx context.Context, name string, args map[string]any) (string, error) {
+	server, client := e.mcpClient(ctx)
+	if server == nil {
+		return "", errors.New("MCP gateway not configured")
+	}
+	result, err := server.CallTool(ctx, client, name, args)
+	if err != nil {
+		return "", err
+	}
+	var out strings.Builder
+	for _, block := range result.Content {
+		out.WriteString(block.Text)
+	}
+	return out.String(), nil
+}
+
+func applyAssistantRequest(a *domain.Assistant, req *AssistantRequest) {
+	if req.Model != nil {
+		a.Model = *req.Model
+	}
+	if req.Name != nil {
+		a.Name = *req.Name
+	}
+	if req.Description != nil {
+		a.Description = *req.Description
+	}
+	if req.Instructions != nil {
+		a.Instructions = *req.Instructions
+	}
+	if req.Tools != nil {
+		a.Tools = *req.Tools
+	}
+	if req.Temperature != nil {
+		a.Temperature = req.Temperature
+	}
+}
+
+// listLimit reads the limit query parameter of a list request
+func listLimit(r *http.Request) int {
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		return 20
+	}
+	return min(limit, assistants.MaxListLimit)
+}
+
+func toAssistantResponse(a *domain.Assistant) AssistantResponse {
+	tools := a.Tools
+	if tools == nil {
+		tools = []domain.AssistantTool{}
+	}
+	metadata := a.Metadata
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+	return AssistantResponse{
+		ID:           a.ID,
+		Object:       "assistant",
+		CreatedAt:    a.CreatedAt.Unix(),
+		Name:         optionalString(a.Name),
+		Description:  optionalString(a.Description),
+		Model:        a.Model,
+		Instructions: optionalString(a.Instructions),
+		Tools:        tools,
+		Temperature:  a.Temperature,
+		Metadata:     metadata,
+	}
+}
+
+func toRunResponse(run *domain.Run) RunResponse {
+	resp := RunResponse{
+		ID:           run.ID,
+		Object:       "thread.run",
+		CreatedAt:    run.CreatedAt.Unix(),
+		ThreadID:     run.ThreadID,
+		AssistantID:  run.AssistantID,
+		Status:       run.Status,
+		LastError:    run.LastError,
+		Model:        run.Model,
+		Instructions: run.Instructions,
+		Tools:        run.Tools,
+		Temperature:  run.Temperature,
+		Metadata:     run.Metadata,
+	}
+	if resp.Tools == nil {
+		resp.Tools = []domain.AssistantTool{}
+	}
+	if resp.Metadata == nil {
+		resp.Metadata = map[string]string{}
+	}
+	if run.Status.IsTerminal() {
+		usage := run.Usage
+		resp.Usage = &usage
+	} else {
+		expiresAt := run.ExpiresAt.Unix()
+		resp.ExpiresAt = &expiresAt
+	}
+	if run.StartedAt != nil {
+		resp.StartedAt = unixPtr(run.StartedAt.Unix())
+	}
+	if run.CancelledAt != nil {
+		resp.CancelledAt = unixPtr(run.CancelledAt.Unix())
+	}
+	if run.FailedAt != nil {
+		resp.FailedAt = unixPtr(run.FailedAt.Unix())
+	}
+	if run.CompletedAt != nil {
+		resp.CompletedAt = unixPtr(run.CompletedAt.Unix())
+	}
+	if run.Status == domain.RunStatusRequiresAction {
+		resp.RequiredAction = &RunRequiredAction{Type: "submit_tool_outputs"}
+		for _, call := range run.RequiredToolCalls {
+			args, _ := json.Marshal(call.Function.Arguments)
+			resp.RequiredAction.SubmitToolOutputs.ToolCalls = append(resp.RequiredAction.SubmitToolOutputs.ToolCalls, ToolCall{
+				ID:       call.ID,
+				Type:     "function",
+				Function: &FunctionCall{Name: call.Function.Name, Arguments: string(args)},
+			})
+		}
+	}
+	return resp
+}
+
+// toAssistantMessage converts a stored message to the Assistants API form.
+// It returns false for tool results and for assistant turns that only call tools.
+func toAssistantMessage(m *domain.ThreadMessage) (AssistantMessageResponse, bool) {
+	if m.Message.Role != "user" && m.Message.Role != "assistant" {
+		return AssistantMessageResponse{}, false
+	}
+	msg := AssistantMessageResponse{
+		ID:          m.ID,
+		Object:      "thread.message",
+		CreatedAt:   m.CreatedAt.Unix(),
+		ThreadID:    m.ThreadID,
+		Status:      "completed",
+		Role:        m.Message.Role,
+		Content:     []AssistantContentPart{},
+		Attachments: []any{},
+		Metadata:    map[string]string{},
+	}
+	for _, block := range m.Message.Content {
+		switch {
+		case block.Type == "text" && block.Text != "":
+			msg.Content = append(msg.Content, AssistantContentPart{
+				Type: "text",
+				Text: &AssistantText{Value: block.Text, Annotations: []any{}},
+			})
+		case block.Type == "image" && block.ImageURL != "":
+			part := AssistantContentPart{Type: "image_url"}
+			part.ImageURL = &struct {
+				URL string `json:"url"`
+			}{URL: block.ImageURL}
+			msg.Content = append(msg.Content, part)
+		}
+	}
+	if len(msg.Content) == 0 {
+		return AssistantMessageResponse{}, false
+	}
+	return msg, true
+}
+
+func unixPtr(t int64) *int64 { return &t }
+
+// optionalString is nil for an empty string, which the Assistants API returns as null
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
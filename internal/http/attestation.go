@@ -0,0 +1,289 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"modelgate/internal/attestation"
+	"modelgate/internal/domain"
+)
+
+// SetAttestation sets the response attestation service
+func (s *Server) SetAttestation(svc *attestation.Service) {
+	s.attestation = svc
+	if s.graphqlResolver != nil {
+		s.graphqlResolver.SetAttestation(svc)
+	}
+}
+
+// requestAttestation holds what a chat completion handler learned that the
+// attestation of its response needs
+type requestAttestation struct {
+	chatReq *domain.ChatRequest
+}
+
+type requestAttestationKey struct{}
+
+// requestAttestationFrom returns the attestation of the request, or nil when
+// responses aren't signed. Its methods accept a nil receiver.
+func requestAttestationFrom(ctx context.Context) *requestAttestation {
+	a, _ := ctx.Value(requestAttestationKey{}).(*requestAttestation)
+	return a
+}
+
+// setChatRequest keeps the request to read its ID and policy version
+func (a *requestAttestation) setChatRequest(req *domain.ChatRequest) {
+	if a != nil {
+		a.chatReq = req
+	}
+}
+
+// detach stops reading the chat request, which a background goroutine now owns
+func (a *requestAttestation) detach() {
+	if a != nil {
+		a.chatReq = nil
+	}
+}
+
+// withAttestation signs the successful responses of a chat completion
+// handler. A JSON response is held back to set the attestation header; a
+// stream passes through and gets the attestation as a trailer.
+func (s *Server) withAttestation(next func(http.ResponseWriter, *http.Request, *AuthContext)) func(http.ResponseWriter, *http.Request, *AuthContext) {
+	return func(w http.ResponseWriter, r *http.Request, auth *AuthContext) {
+		if !s.attestation.Enabled() {
+			next(w, r, auth)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			s.writeError(w, http.StatusBadRequest, "invalid_request_error", "Failed to read request body")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		a := &requestAttestation{}
+		aw := &attestingWriter{ResponseWriter: w}
+		next(aw, r.WithContext(context.WithValue(r.Context(), requestAttestationKey{}, a)), auth)
+
+		var signed *domain.ResponseAttestation
+		if req := a.chatReq; req != nil && aw.status() == http.StatusOK {
+			signed, err = s.attestation.Sign(req.RequestID, attestation.Hash(body), attestation.Hash(aw.body.Bytes()), req.PolicyVersion)
+			if err != nil {
+				slog.Error("Failed to sign response attestation", "request_id", req.RequestID, "error", err)
+			}
+		}
+		if signed != nil {
+			w.Header().Set(attestation.HeaderName, attestation.FormatHeader(signed))
+			s.attestation.Record(signed)
+		}
+		aw.finish()
+	}
+}
+
+// attestingWriter hashes a response on its way out. Responses other than
+// event streams are buffered until finish.
+type attestingWriter struct {
+	http.ResponseWriter
+	code      int
+	streaming bool
+	body      bytes.Buffer
+}
+
+func (w *attestingWriter) WriteHeader(code int) {
+	if w.code != 0 {
+		return
+	}
+	w.code = code
+	if strings.HasPrefix(w.Header().Get("Content-Type"), "text/event-stream") {
+		w.streaming = true
+		w.Header().Add("Trailer", attestation.HeaderName)
+		w.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (w *attestingWriter) Write(p []byte) (int, error) {
+	if w.code == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	w.body.Write(p)
+	if !w.streaming {
+		return len(p), nil
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *attestingWriter) Flush() {
+	if !w.streaming {
+		return
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *attestingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *attestingWriter) status() int {
+	if w.code == 0 {
+		return http.StatusOK
+	}
+	return w.code
+}
+
+// finish writes a buffered response
+func (w *attestingWriter) finish() {
+	if w.streaming {
+		return
+	}
+	w.ResponseWriter.WriteHeader(w.status())
+	w.ResponseWriter.Write(w.body.Bytes())
+}
+
+// AttestationResponse is an attestation as returned by the API
+type AttestationResponse struct {
+	RequestID     string `json:"request_id"`
+	KeyID         string `json:"key_id"`
+	RequestHash   string `json:"request_hash"`
+	ResponseHash  string `json:"response_hash"`
+	PolicyVersion string `json:"policy_version"`
+	SignedAt      int64  `json:"signed_at"`
+	Signature     string `json:"signature"` // Base64url, unpadded
+}
+
+func newAttestationResponse(a *domain.ResponseAttestation) *AttestationResponse {
+	return &AttestationResponse{
+		RequestID:     a.RequestID,
+		KeyID:         a.KeyID,
+		RequestHash:   a.RequestHash,
+		ResponseHash:  a.ResponseHash,
+		PolicyVersion: a.PolicyVersion,
+		SignedAt:      a.SignedAt.Unix(),
+		Signature:     base64.RawURLEncoding.EncodeToString(a.Signature),
+	}
+}
+
+// VerifyAttestationRequest asks to verify an attestation header value, or
+// the attestation stored for a request
+type VerifyAttestationRequest struct {
+	Attestation string `json:"attestation,omitempty"`
+	RequestID   string `json:"request_id,omitempty"`
+}
+
+// VerifyAttestationResponse is the result of a verification
+type VerifyAttestationResponse struct {
+	Valid       bool                 `json:"valid"`  // The signature matches a key of this gateway
+	Stored      bool                 `json:"stored"` // The gateway holds this same attestation for the request
+	Error       string               `json:"error,omitempty"`
+	Attestation *AttestationResponse `json:"attestation,omitempty"`
+}
+
+// handleVerifyAttestation checks the signature of an attestation and whether
+// it matches the one stored for its request. Clients compare the hashes with
+// the SHA-256 of the bodies they sent and received.
+func (s *Server) handleVerifyAttestation(w http.ResponseWriter, r *http.Request, auth *AuthContext) {
+	if s.attestation == nil {
+		s.writeError(w, http.StatusNotFound, "not_found", "Response attestations are not enabled")
+		return
+	}
+	var req VerifyAttestationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, http.StatusBadRequest, "invalid_request_error", "Invalid request body: "+err.Error())
+		return
+	}
+
+	ctx := r.Context()
+	var att *domain.ResponseAttestation
+	switch {
+	case req.Attestation != "":
+		parsed, err := attestation.ParseHeader(req.Attestation)
+		if err != nil {
+			s.writeJSON(w, http.StatusOK, VerifyAttestationResponse{Error: err.Error()})
+			return
+		}
+		att = parsed
+	case req.RequestID != "":
+		stored, err := s.attestation.Lookup(ctx, req.RequestID)
+		if err != nil {
+			s.writeError(w, http.StatusInternalServerError, "server_error", "Failed to look up attestation")
+			return
+		}
+		if stored == nil {
+			s.writeError(w, http.StatusNotFound, "not_found", "No attestation stored for this request")
+			return
+		}
+		att = stored
+	default:
+		s.writeError(w, http.StatusBadRequest, "invalid_request_error", "attestation or request_id is required")
+		return
+	}
+
+	resp := VerifyAttestationResponse{Attestation: newAttestationResponse(att)}
+	if err := s.attestation.Verify(ctx, att); err != nil {
+		if !errors.Is(err, attestation.ErrUnknownKey) && !errors.Is(err, attestation.ErrInvalidSignature) {
+			s.writeError(w, http.StatusInternalServerError, "server_error", "Failed to verify attestation")
+			return
+		}
+		resp.Error = err.Error()
+	} else {
+		resp.Valid = true
+	}
+	stored, err := s.attestation.Lookup(ctx, att.RequestID)
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, "server_error", "Failed to look up attestation")
+		return
+	}
+	resp.Stored = stored != nil && sameAttestation(stored, att)
+	s.writeJSON(w, http.StatusOK, resp)
+}
+
+func sameAttestation(a, b *domain.ResponseAttestation) bool {
+	return a.KeyID == b.KeyID && a.RequestHash == b.RequestHash && a.ResponseHash == b.ResponseHash &&
+		a.PolicyVersion == b.PolicyVersion && a.SignedAt.Equal(b.SignedAt) &&
+		subtle.ConstantTimeCompare(a.Signature, b.Signature) == 1
+}
+
+// AttestationKeyResponse is a public signing key
+type AttestationKeyResponse struct {
+	ID        string     `json:"id"`
+	Algorithm string     `json:"algorithm"`
+	PublicKey string     `json:"public_key"` // Base64 of the raw 32-byte key
+	CreatedAt time.Time  `json:"created_at"`
+	RetiredAt *time.Time `json:"retired_at,omitempty"`
+}
+
+// handleListAttestationKeys returns the public keys that signed attestations,
+// so they can be verified offline
+func (s *Server) handleListAttestationKeys(w http.ResponseWriter, r *http.Request, auth *AuthContext) {
+	if s.attestation == nil {
+		s.writeError(w, http.StatusNotFound, "not_found", "Response attestations are not enabled")
+		return
+	}
+	keys, err := s.attestation.Keys(r.Context())
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, "server_error", "Failed to list attestation keys")
+		return
+	}
+	out := make([]AttestationKeyResponse, 0, len(keys))
+	for _, k := range keys {
+		out = append(out, AttestationKeyResponse{
+			ID:        k.ID,
+			Algorithm: "Ed25519",
+			PublicKey: base64.StdEncoding.EncodeToString(k.PublicKey),
+			CreatedAt: k.CreatedAt,
+			RetiredAt: k.RetiredAt,
+		})
+	}
+	s.writeJSON(w, http.StatusOK, map[string]any{"object": "list", "data": out})
+}
@@ -0,0 +1,41 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"modelgate/internal/auditexport"
+)
+
+// handleDownloadAuditExport serves a finished audit log export. The token in
+// the download URL returned by GraphQL authorizes the request.
+func (s *Server) handleDownloadAuditExport(w http.ResponseWriter, r *http.Request) {
+	if s.auditExport == nil {
+		s.writeError(w, http.StatusNotFound, "not_found", "Audit export is not configured")
+		return
+	}
+
+	job, content, err := s.auditExport.Download(r.Context(), r.PathValue("job_id"), r.URL.Query().Get("token"))
+	if errors.Is(err, auditexport.ErrDownloadUnavailable) {
+		s.writeError(w, http.StatusNotFound, "not_found", err.Error())
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to load audit export", "job_id", r.PathValue("job_id"), "error", err)
+		s.writeError(w, http.StatusInternalServerError, "server_error", "Failed to load export")
+		return
+	}
+
+	contentType := "application/x-ndjson"
+	if job.Format == "csv" {
+		contentType = "text/csv"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", auditexport.FileName(job)))
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(content)
+}
@@ -0,0 +1,275 @@
+package http
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"modelgate/internal/batch"
+	"modelgate/internal/domain"
+	"modelgate/internal/files"
+)
+
+// maxBatchRequests limits the number of lines in a batch input file
+const maxBatchRequests = 50000
+
+// CreateBatchRequest is the body for POST /v1/batches
+type CreateBatchRequest struct {
+	InputFileID      string         `json:"input_file_id"`
+	Endpoint         string         `json:"endpoint"`
+	CompletionWindow string         `json:"completion_window"`
+	Metadata         map[string]any `json:"metadata,omitempty"`
+}
+
+// BatchInputLine is one line of a batch input file
+type BatchInputLine struct {
+	CustomID string                `json:"custom_id"`
+	Method   string                `json:"method"`
+	URL      string                `json:"url"`
+	Body     ChatCompletionRequest `json:"body"`
+}
+
+// BatchResponse is the OpenAI-style batch object
+type BatchResponse struct {
+	ID               string             `json:"id"`
+	Object           string             `json:"object"` // "batch"
+	Endpoint         string             `json:"endpoint"`
+	InputFileID      string             `json:"input_file_id"`
+	OutputFileID     *string            `json:"output_file_id"`
+	CompletionWindow string             `json:"completion_window"`
+	Status           string             `json:"status"`
+	Model            string             `json:"model"`
+	Provider         string             `json:"provider"`
+	RequestCounts    domain.BatchCounts `json:"request_counts"`
+	Errors           *BatchErrors       `json:"errors"`
+	Metadata         map[string]any     `json:"metadata,omitempty"`
+	CreatedAt        int64              `json:"created_at"`
+	CompletedAt      *int64             `json:"completed_at"`
+}
+
+// BatchErrors is the OpenAI-style errors list of a batch
+type BatchErrors struct {
+	Object string       `json:"object"` // "list"
+	Data   []BatchError `json:"data"`
+}
+
+// BatchError describes why a batch (or one of its input lines) failed
+type BatchError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Line    *int   `json:"line,omitempty"`
+}
+
+// SetBatchService enables the batches endpoints
+func (s *Server) SetBatchService(svc *batch.Service) {
+	s.batchService = svc
+	s.mux = http.NewServeMux()
+	s.setupRoutes()
+}
+
+// handleCreateBatch handles POST /v1/batches
+// Each input line is converted and policy-checked like a regular chat completion before submission.
+func (s *Server) handleCreateBatch(w http.ResponseWriter, r *http.Request, auth *AuthContext) {
+	var req CreateBatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON body")
+		return
+	}
+	if req.InputFileID == "" {
+		s.writeError(w, http.StatusBadRequest, "invalid_request", "input_file_id is required")
+		return
+	}
+	if req.Endpoint == "" {
+		req.Endpoint = "/v1/chat/completions"
+	}
+	if req.Endpoint != "/v1/chat/completions" {
+		s.writeError(w, http.StatusBadRequest, "invalid_request", "only /v1/chat/completions is supported for batches")
+		return
+	}
+
+	file, content, err := s.fileService.OpenFile(r.Context(), req.InputFileID)
+	if err == nil && !canAccessFile(file, auth) {
+		content.Close()
+		err = files.ErrFileNotFound
+	}
+	if err != nil {
+		s.writeBatchError(w, err)
+		return
+	}
+	defer content.Close()
+
+	var items []domain.BatchItem
+	scanner := bufio.NewScanner(content)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var line BatchInputLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			s.writeError(w, http.StatusBadRequest, "invalid_request", fmt.Sprintf("line %d: invalid JSON", lineNum))
+			return
+		}
+		if line.URL != "" && line.URL != req.Endpoint {
+			s.writeError(w, http.StatusBadRequest, "invalid_request", fmt.Sprintf("line %d: url must be %s", lineNum, req.Endpoint))
+			return
+		}
+		if line.Body.Stream {
+			s.writeError(w, http.StatusBadRequest, "invalid_request", fmt.Sprintf("line %d: streaming is not supported in batches", lineNum))
+			return
+		}
+
+		if _, err := parseResponseFormat(line.Body.ResponseFormat); err != nil {
+			s.writeError(w, http.StatusBadRequest, "invalid_request", fmt.Sprintf("line %d: %v", lineNum, err))
+			return
+		}
+		if _, err := parseToolChoice(line.Body.ToolChoice); err != nil {
+			s.writeError(w, http.StatusBadRequest, "invalid_request", fmt.Sprintf("line %d: %v", lineNum, err))
+			return
+		}
+
+		domainReq := s.convertChatRequest(&line.Body)
+		domainReq.Model = s.config.Load().ResolveModel(domainReq.Model)
+		if auth.APIKey != nil {
+			domainReq.APIKeyID = auth.APIKey.ID
+			domainReq.RoleID = auth.APIKey.RoleID
+			domainReq.GroupID = auth.APIKey.GroupID
+			domainReq.ProjectID = auth.APIKey.ProjectID
+		}
+		if _, err := s.enforcePoliciesForRequest(r.Context(), domainReq, auth); err != nil {
+			s.writePolicyViolationError(w, err)
+			return
+		}
+
+		items = append(items, domain.BatchItem{CustomID: line.CustomID, Request: domainReq})
+		if len(items) > maxBatchRequests {
+			s.writeError(w, http.StatusBadRequest, "invalid_request", fmt.Sprintf("batch exceeds %d requests", maxBatchRequests))
+			return
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		s.writeError(w, http.StatusBadRequest, "invalid_request", fmt.Sprintf("reading input file: %v", err))
+		return
+	}
+
+	created, err := s.batchService.Create(r.Context(), batch.CreateRequest{
+		APIKeyID:         authAPIKeyID(auth),
+		Endpoint:         req.Endpoint,
+		InputFileID:      req.InputFileID,
+		CompletionWindow: req.CompletionWindow,
+		Metadata:         req.Metadata,
+		Items:            items,
+	})
+	if err != nil {
+		s.writeBatchError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, toBatchResponse(created))
+}
+
+// handleListBatches handles GET /v1/batches
+func (s *Server) handleListBatches(w http.ResponseWriter, r *http.Request, auth *AuthContext) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	batches, err := s.batchService.List(r.Context(), authAPIKeyID(auth), limit)
+	if err != nil {
+		s.writeBatchError(w, err)
+		return
+	}
+
+	data := make([]*BatchResponse, len(batches))
+	for i, b := range batches {
+		data[i] = toBatchResponse(b)
+	}
+	s.writeJSON(w, http.StatusOK, map[string]any{
+		"object": "list",
+		"data":   data,
+	})
+}
+
+// handleGetBatch handles GET /v1/batches/{batch_id}
+func (s *Server) handleGetBatch(w http.ResponseWriter, r *http.Request, auth *AuthContext) {
+	b, err := s.batchService.Get(r.Context(), r.PathValue("batch_id"))
+	if err == nil && !canAccessBatch(b, auth) {
+		err = batch.ErrBatchNotFound
+	}
+	if err != nil {
+		s.writeBatchError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, toBatchResponse(b))
+}
+
+// handleCancelBatch handles POST /v1/batches/{batch_id}/cancel
+func (s *Server) handleCancelBatch(w http.ResponseWriter, r *http.Request, auth *AuthContext) {
+	batchID := r.PathValue("batch_id")
+	b, err := s.batchService.Get(r.Context(), batchID)
+	if err == nil && !canAccessBatch(b, auth) {
+		err = batch.ErrBatchNotFound
+	}
+	if err == nil {
+		b, err = s.batchService.Cancel(r.Context(), batchID)
+	}
+	if err != nil {
+		s.writeBatchError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, toBatchResponse(b))
+}
+
+// canAccessBatch applies the same ownership rule as uploads
+func canAccessBatch(b *domain.Batch, auth *AuthContext) bool {
+	if b.APIKeyID == "" || auth.APIKey == nil {
+		return true
+	}
+	return b.APIKeyID == auth.APIKey.ID
+}
+
+// writeBatchError maps batch service errors to HTTP responses
+func (s *Server) writeBatchError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, batch.ErrBatchNotFound), errors.Is(err, files.ErrFileNotFound):
+		s.writeError(w, http.StatusNotFound, "not_found", err.Error())
+	case errors.Is(err, batch.ErrBatchNotCancellable):
+		s.writeError(w, http.StatusConflict, "invalid_request", err.Error())
+	case errors.Is(err, batch.ErrInvalidBatch), errors.Is(err, batch.ErrBatchNotSupported):
+		s.writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+	default:
+		slog.Error("batch request failed", "error", err)
+		s.writeError(w, http.StatusInternalServerError, "server_error", fmt.Sprintf("batch request failed: %v", err))
+	}
+}
+
+func toBatchResponse(b *domain.Batch) *BatchResponse {
+	resp := &BatchResponse{
+		ID:               b.ID,
+		Object:           "batch",
+		Endpoint:         b.Endpoint,
+		InputFileID:      b.InputFileID,
+		CompletionWindow: b.CompletionWindow,
+		Status:           string(b.Status),
+		Model:            b.Model,
+		Provider:         string(b.Provider),
+		RequestCounts:    b.RequestCounts,
+		Metadata:         b.Metadata,
+		CreatedAt:        b.CreatedAt.Unix(),
+	}
+	if b.OutputFileID != "" {
+		resp.OutputFileID = &b.OutputFileID
+	}
+	if b.Errors != "" {
+		resp.Errors = &BatchErrors{
+			Object: "list",
+			Data:   []BatchError{{Code: "provider_error", Message: b.Errors}},
+		}
+	}
+	if b.CompletedAt != nil {
+		completedAt := b.CompletedAt.Unix()
+		resp.CompletedAt = &completedAt
+	}
+	return resp
+}
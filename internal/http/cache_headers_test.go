@@ -0,0 +1,57 @@
+package http
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+func TestCacheHeaders(t *testing.T) {
+	w := httptest.NewRecorder()
+	setCacheHeaders(w, &domain.CacheLookup{
+		Status:     domain.CacheStatusSemanticHit,
+		Similarity: 0.9731,
+		StoredAt:   time.Now().Add(-90 * time.Second),
+	})
+	if got := w.Header().Get("X-ModelGate-Cache"); got != "semantic-hit" {
+		t.Errorf("X-ModelGate-Cache = %q, want semantic-hit", got)
+	}
+	if got := w.Header().Get("X-ModelGate-Cache-Similarity"); got != "0.9731" {
+		t.Errorf("X-ModelGate-Cache-Similarity = %q, want 0.9731", got)
+	}
+	if got := w.Header().Get("Age"); got != "90" {
+		t.Errorf("Age = %q, want 90", got)
+	}
+
+	w = httptest.NewRecorder()
+	setCacheHeaders(w, &domain.CacheLookup{Status: domain.CacheStatusMiss})
+	if w.Header().Get("X-ModelGate-Cache") != "miss" || w.Header().Get("Age") != "" {
+		t.Errorf("miss headers = %v", w.Header())
+	}
+}
+
+func TestETagOnlyForDeterministicRequests(t *testing.T) {
+	zero, warm := float32(0), float32(0.7)
+	resp := &domain.ChatResponse{Content: "4", FinishReason: domain.FinishReasonStop}
+
+	etag := func(temperature *float32, resp *domain.ChatResponse) string {
+		w := httptest.NewRecorder()
+		setETag(w, &ChatCompletionRequest{Model: "gpt-4o", Temperature: temperature}, resp)
+		return w.Header().Get("ETag")
+	}
+	first := etag(&zero, resp)
+	if first == "" {
+		t.Fatal("no ETag for a temperature 0 request")
+	}
+	if again := etag(&zero, &domain.ChatResponse{Content: "4", FinishReason: domain.FinishReasonStop}); again != first {
+		t.Errorf("identical output got ETag %s, want %s", again, first)
+	}
+	if other := etag(&zero, &domain.ChatResponse{Content: "5", FinishReason: domain.FinishReasonStop}); other == first {
+		t.Error("different output got the same ETag")
+	}
+	if etag(&warm, resp) != "" || etag(nil, resp) != "" {
+		t.Error("ETag set for a non-deterministic request")
+	}
+}
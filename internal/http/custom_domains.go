@@ -0,0 +1,93 @@
+package http
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"modelgate/internal/customdomains"
+	"modelgate/internal/domain"
+)
+
+// SetCustomDomains sets the custom domain service. Call before Start.
+func (s *Server) SetCustomDomains(svc *customdomains.Service) {
+	s.domains = svc
+	if s.graphqlResolver != nil {
+		s.graphqlResolver.SetCustomDomains(svc)
+	}
+}
+
+type hostTenantKey struct{}
+
+// hostTenant returns the tenant of the custom domain a request was sent to,
+// or "" for other hostnames
+func hostTenant(ctx context.Context) string {
+	slug, _ := ctx.Value(hostTenantKey{}).(string)
+	return slug
+}
+
+// hostMiddleware resolves the tenant of the custom domain a request was sent
+// to. With enforce_hosts, requests for hostnames that are neither registered
+// nor allowed get 421, except health probes, which load balancers often send
+// to an IP address.
+func (s *Server) hostMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.domains == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if d := s.domains.Resolve(r.Host); d != nil {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), hostTenantKey{}, d.TenantSlug)))
+			return
+		}
+		cfg := s.config.Load().Domains
+		probe := strings.HasPrefix(r.URL.Path, "/health") || strings.HasPrefix(r.URL.Path, "/ready")
+		if cfg.EnforceHosts && !probe && !customdomains.HostAllowed(r.Host, cfg.AllowedHosts) {
+			s.writeError(w, http.StatusMisdirectedRequest, "unknown_host", "This gateway does not serve "+customdomains.NormalizeHost(r.Host))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// hostServesTenant reports whether an authenticated tenant may be served on
+// the hostname of the request. Custom domains only serve their own tenant;
+// other hostnames serve every tenant.
+func hostServesTenant(r *http.Request, tenant *domain.Tenant) bool {
+	slug := hostTenant(r.Context())
+	if slug == "" || tenant == nil {
+		return true
+	}
+	if s, ok := tenant.Metadata["slug"]; ok {
+		return s == slug
+	}
+	return tenant.ID == slug
+}
+
+// serveACMEChallenges answers ACME HTTP-01 challenges on acme_http_port
+// until ctx is cancelled
+func (s *Server) serveACMEChallenges(ctx context.Context, addr string) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = ""
+	}
+	server := &http.Server{
+		Addr:              net.JoinHostPort(host, strconv.Itoa(s.domains.ACMEHTTPPort())),
+		Handler:           s.domains.HTTPHandler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+	slog.Info("Answering ACME HTTP-01 challenges", "addr", server.Addr)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		slog.Error("ACME challenge listener failed", "addr", server.Addr, "error", err)
+	}
+}
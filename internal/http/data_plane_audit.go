@@ -0,0 +1,173 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"modelgate/internal/dataaudit"
+	"modelgate/internal/domain"
+	"modelgate/internal/policy"
+
+	"github.com/google/uuid"
+)
+
+// SetDataAudit sets the data-plane audit service
+func (s *Server) SetDataAudit(svc *dataaudit.Service) {
+	s.dataAudit = svc
+	if s.graphqlResolver != nil {
+		s.graphqlResolver.SetDataAudit(svc)
+	}
+}
+
+// requestAudit collects the data-plane audit event of a request while its
+// handlers run
+type requestAudit struct {
+	event   domain.DataPlaneAuditEvent
+	chatReq *domain.ChatRequest // Read once the request is done, for the served model, cache and routing
+}
+
+type requestAuditKey struct{}
+
+// requestAuditFrom returns the audit of the request, or nil when data-plane
+// audit is off. Its methods accept a nil receiver.
+func requestAuditFrom(ctx context.Context) *requestAudit {
+	a, _ := ctx.Value(requestAuditKey{}).(*requestAudit)
+	return a
+}
+
+// setAPIKey records the key that authenticated the request
+func (a *requestAudit) setAPIKey(key *domain.APIKey) {
+	if a == nil || key == nil {
+		return
+	}
+	a.event.APIKeyID = key.ID
+	a.event.RoleID = key.RoleID
+	a.event.ProjectID = key.ProjectID
+}
+
+// setChatRequest records the model asked for and keeps the request to read
+// what the gateway did with it
+func (a *requestAudit) setChatRequest(req *domain.ChatRequest) {
+	if a == nil {
+		return
+	}
+	a.event.Model = req.Model
+	a.chatReq = req
+}
+
+// detach stops reading the chat request, which a background goroutine now owns
+func (a *requestAudit) detach() {
+	if a != nil {
+		a.chatReq = nil
+	}
+}
+
+// setPolicyOutcome records the outcome of policy enforcement
+func (a *requestAudit) setPolicyOutcome(result *ToolPolicyResult, err error) {
+	if a == nil {
+		return
+	}
+	if err != nil {
+		a.event.PolicyDecision = "blocked"
+		var violation *policy.PolicyViolation
+		if errors.As(err, &violation) {
+			a.event.PolicyCode = violation.Code
+		}
+		return
+	}
+	a.event.PolicyDecision = "allowed"
+	if result != nil {
+		a.event.ToolsAllowed = result.AllowedTools
+		a.event.ToolsRemoved = result.RemovedTools
+		a.event.ParamsClamped = result.ClampedParams
+	}
+}
+
+// finish completes the event once the response was written
+func (a *requestAudit) finish(status int, latency time.Duration) {
+	a.event.StatusCode = status
+	a.event.LatencyMs = latency.Milliseconds()
+	req := a.chatReq
+	if req == nil {
+		return
+	}
+	a.event.RequestID = req.RequestID
+	if a.event.PolicyDecision == "blocked" {
+		return
+	}
+	a.event.ServedModel = req.Model
+	if req.CacheLookup != nil {
+		a.event.CacheStatus = req.CacheLookup.Status
+	}
+	if req.RoutingDecision != nil {
+		a.event.RoutingStrategy = req.RoutingDecision.Strategy
+	}
+}
+
+// dataPlaneAuditMiddleware records an audit event for each /v1 request while
+// data-plane audit is enabled
+func (s *Server) dataPlaneAuditMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/v1/") || !s.dataAudit.Enabled() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+		a := &requestAudit{event: domain.DataPlaneAuditEvent{
+			ID:        uuid.New().String(),
+			CreatedAt: start.UTC(),
+			Method:    r.Method,
+			Path:      r.URL.Path,
+		}}
+		if ip := clientIP(r, s.config.Load().Server.TrustedProxies); ip.IsValid() {
+			a.event.ClientIP = ip.String()
+		}
+		sw := &statusWriter{ResponseWriter: w}
+		next.ServeHTTP(sw, r.WithContext(context.WithValue(r.Context(), requestAuditKey{}, a)))
+
+		a.finish(sw.status(), time.Since(start))
+		s.dataAudit.Record(a.event)
+	})
+}
+
+// statusWriter remembers the status of the response passing through it
+type statusWriter struct {
+	http.ResponseWriter
+	code int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(p []byte) (int, error) {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *statusWriter) status() int {
+	if w.code == 0 {
+		return http.StatusOK
+	}
+	return w.code
+}
@@ -0,0 +1,58 @@
+package http
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"modelgate/internal/deprecation"
+	"modelgate/internal/domain"
+	"modelgate/internal/policy"
+)
+
+// SetDeprecations sets the model deprecation service
+func (s *Server) SetDeprecations(svc *deprecation.Service) {
+	s.deprecations = svc
+	if s.graphqlResolver != nil {
+		s.graphqlResolver.SetDeprecations(svc)
+	}
+}
+
+// applyModelDeprecation checks the requested model against the deprecations
+// and returns the X-ModelGate-Deprecation value to send, if any. When a role
+// has rewrite_deprecated_models and the model has a replacement, the request
+// is moved to the replacement, before or after the sunset. Otherwise a
+// retired model is refused.
+func (s *Server) applyModelDeprecation(req *domain.ChatRequest, rolePolicies []*domain.RolePolicy) (string, error) {
+	d := s.deprecations.Lookup(req.Model)
+	if d == nil {
+		return "", nil
+	}
+
+	rewrite := false
+	for _, p := range rolePolicies {
+		if p.ModelRestriction.RewriteDeprecatedModels {
+			rewrite = true
+			break
+		}
+	}
+	if rewrite && d.ReplacementModel != "" {
+		requested := req.Model
+		req.Model = d.ReplacementModel
+		slog.Debug("Moved request off deprecated model", "request_id", req.RequestID, "model", requested, "replacement", d.ReplacementModel)
+		return deprecation.Header(d, requested, d.ReplacementModel), nil
+	}
+
+	if d.Sunset(time.Now()) {
+		message := fmt.Sprintf("Model %s was retired on %s", req.Model, d.SunsetAt.UTC().Format(time.DateOnly))
+		if d.ReplacementModel != "" {
+			message += "; use " + d.ReplacementModel + " instead"
+		}
+		return "", &policy.PolicyViolation{
+			Code:    "model_sunset",
+			Message: message,
+			Type:    "sunset",
+		}
+	}
+	return deprecation.Header(d, req.Model, ""), nil
+}
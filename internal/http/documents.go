@@ -0,0 +1,278 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"modelgate/internal/documents"
+	"modelgate/internal/domain"
+	"modelgate/internal/files"
+)
+
+// CreateDocumentRequest is the JSON body for POST /v1/documents, ingesting a
+// file uploaded through /v1/files
+type CreateDocumentRequest struct {
+	FileID     string `json:"file_id"`
+	Collection string `json:"collection,omitempty"`
+}
+
+// DocumentResponse is a stored document
+type DocumentResponse struct {
+	ID         string `json:"id"`
+	Object     string `json:"object"` // "document"
+	Collection string `json:"collection"`
+	Filename   string `json:"filename"`
+	MimeType   string `json:"mime_type"`
+	Bytes      int64  `json:"bytes"`
+	Status     string `json:"status"` // processing, ready, failed
+	Error      string `json:"error,omitempty"`
+	Pages      int    `json:"pages"`
+	OCRPages   int    `json:"ocr_pages"`
+	Chunks     int    `json:"chunks"`
+	CreatedAt  int64  `json:"created_at"`
+}
+
+// DocumentListResponse is the body of GET /v1/documents
+type DocumentListResponse struct {
+	Object string              `json:"object"` // "list"
+	Data   []*DocumentResponse `json:"data"`
+}
+
+// DocumentDeletedResponse is the body of DELETE /v1/documents/{document_id}
+type DocumentDeletedResponse struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"` // "document"
+	Deleted bool   `json:"deleted"`
+}
+
+// errDocumentsDisabled is returned for document references while document
+// retrieval is off
+var errDocumentsDisabled = errors.New("documents reference stored documents, but [documents] is not enabled")
+
+// SetDocuments enables document ingestion and retrieval
+func (s *Server) SetDocuments(svc *documents.Service) {
+	s.documents = svc
+	s.mux = http.NewServeMux()
+	s.setupRoutes()
+}
+
+// handleCreateDocument handles POST /v1/documents. The body is either a
+// multipart form with the file and its collection, or JSON naming a file
+// uploaded through /v1/files.
+func (s *Server) handleCreateDocument(w http.ResponseWriter, r *http.Request, auth *AuthContext) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/json" {
+		s.createDocumentFromFile(w, r, auth)
+		return
+	}
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "invalid_request", "Expected a multipart/form-data or JSON body")
+		return
+	}
+	req := documents.IngestRequest{APIKeyID: authAPIKeyID(auth)}
+	// Fields are read in order; the collection must come before the file
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			s.writeError(w, http.StatusBadRequest, "invalid_request", "Invalid multipart body")
+			return
+		}
+		switch part.FormName() {
+		case "collection":
+			value, err := io.ReadAll(io.LimitReader(part, 1024))
+			if err != nil {
+				s.writeError(w, http.StatusBadRequest, "invalid_request", "Invalid multipart body")
+				return
+			}
+			req.Collection = string(value)
+		case "file":
+			req.Filename = part.FileName()
+			req.MimeType = part.Header.Get("Content-Type")
+			doc, err := s.documents.Ingest(r.Context(), req, part)
+			if err != nil {
+				s.writeDocumentError(w, err)
+				return
+			}
+			s.writeJSON(w, http.StatusOK, toDocumentResponse(doc))
+			return
+		}
+		part.Close()
+	}
+	s.writeError(w, http.StatusBadRequest, "invalid_request", "file is required")
+}
+
+// createDocumentFromFile ingests a file uploaded through /v1/files
+func (s *Server) createDocumentFromFile(w http.ResponseWriter, r *http.Request, auth *AuthContext) {
+	var req CreateDocumentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON body")
+		return
+	}
+	if req.FileID == "" {
+		s.writeError(w, http.StatusBadRequest, "invalid_request", "file_id is required")
+		return
+	}
+	if s.fileService == nil {
+		s.writeError(w, http.StatusBadRequest, "invalid_request", "File uploads are not enabled; post the file as multipart/form-data")
+		return
+	}
+
+	file, content, err := s.fileService.OpenFile(r.Context(), req.FileID)
+	if err == nil && !canAccessFile(file, auth) {
+		content.Close()
+		err = files.ErrFileNotFound
+	}
+	if err != nil {
+		s.writeUploadError(w, err)
+		return
+	}
+	defer content.Close()
+
+	doc, err := s.documents.Ingest(r.Context(), documents.IngestRequest{
+		Filename:   file.Filename,
+		MimeType:   file.MimeType,
+		Collection: req.Collection,
+		APIKeyID:   authAPIKeyID(auth),
+	}, content)
+	if err != nil {
+		s.writeDocumentError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, toDocumentResponse(doc))
+}
+
+// handleListDocuments handles GET /v1/documents
+func (s *Server) handleListDocuments(w http.ResponseWriter, r *http.Request, auth *AuthContext) {
+	limit := 100
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 10000 {
+			s.writeError(w, http.StatusBadRequest, "invalid_request", "limit must be between 1 and 10000")
+			return
+		}
+		limit = n
+	}
+
+	list, err := s.documents.List(r.Context(), authAPIKeyID(auth), r.URL.Query().Get("collection"), limit)
+	if err != nil {
+		s.writeDocumentError(w, err)
+		return
+	}
+	resp := DocumentListResponse{Object: "list", Data: make([]*DocumentResponse, 0, len(list))}
+	for _, d := range list {
+		resp.Data = append(resp.Data, toDocumentResponse(d))
+	}
+	s.writeJSON(w, http.StatusOK, resp)
+}
+
+// handleGetDocument handles GET /v1/documents/{document_id}
+func (s *Server) handleGetDocument(w http.ResponseWriter, r *http.Request, auth *AuthContext) {
+	doc, err := s.accessibleDocument(r, auth)
+	if err != nil {
+		s.writeDocumentError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, toDocumentResponse(doc))
+}
+
+// handleDeleteDocument handles DELETE /v1/documents/{document_id}
+func (s *Server) handleDeleteDocument(w http.ResponseWriter, r *http.Request, auth *AuthContext) {
+	doc, err := s.accessibleDocument(r, auth)
+	if err == nil {
+		err = s.documents.Delete(r.Context(), doc.ID)
+	}
+	if err != nil {
+		s.writeDocumentError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, DocumentDeletedResponse{ID: doc.ID, Object: "document", Deleted: true})
+}
+
+// accessibleDocument returns the document of the request path if the caller
+// can see it
+func (s *Server) accessibleDocument(r *http.Request, auth *AuthContext) (*domain.StoredDocument, error) {
+	doc, err := s.documents.Get(r.Context(), r.PathValue("document_id"))
+	if err != nil {
+		return nil, err
+	}
+	if !documents.CanAccess(doc, authAPIKeyID(auth)) {
+		return nil, documents.ErrNotFound
+	}
+	return doc, nil
+}
+
+// retrieveDocuments replaces the request's references to stored documents
+// with the chunks most similar to its last user message, and keeps them to
+// return with the response
+func (s *Server) retrieveDocuments(ctx context.Context, domainReq *domain.ChatRequest, req *ChatCompletionRequest, auth *AuthContext) error {
+	referenced := false
+	for _, d := range domainReq.Documents {
+		referenced = referenced || d.Reference()
+	}
+	if !referenced {
+		return nil
+	}
+	if s.documents == nil {
+		return errDocumentsDisabled
+	}
+
+	matches, err := s.documents.Resolve(ctx, domainReq, authAPIKeyID(auth))
+	if err != nil {
+		return err
+	}
+	for _, m := range matches {
+		doc := documents.ChunkDocument(m)
+		doc.AdditionalProps["score"] = strconv.FormatFloat(m.Score, 'f', 3, 64)
+		req.retrieved = append(req.retrieved, ChatDocument{ID: doc.ID, Text: doc.Text, Fields: doc.AdditionalProps})
+	}
+	return nil
+}
+
+// writeDocumentError maps document service errors to HTTP responses
+func (s *Server) writeDocumentError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, documents.ErrNotFound):
+		s.writeError(w, http.StatusNotFound, "not_found", err.Error())
+	case errors.Is(err, documents.ErrNotReady):
+		s.writeError(w, http.StatusConflict, "invalid_request", err.Error())
+	case errors.Is(err, documents.ErrTooLarge):
+		s.writeError(w, http.StatusRequestEntityTooLarge, "invalid_request", err.Error())
+	case errors.Is(err, documents.ErrUnsupportedType):
+		s.writeError(w, http.StatusUnsupportedMediaType, "invalid_request", err.Error())
+	case errors.Is(err, documents.ErrOCRDisabled), errors.Is(err, documents.ErrInvalidDocument),
+		errors.Is(err, documents.ErrNoQuestion), errors.Is(err, errDocumentsDisabled):
+		s.writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+	default:
+		slog.Error("document request failed", "error", err)
+		s.writeError(w, http.StatusInternalServerError, "server_error", fmt.Sprintf("document request failed: %v", err))
+	}
+}
+
+func toDocumentResponse(d *domain.StoredDocument) *DocumentResponse {
+	return &DocumentResponse{
+		ID:         d.ID,
+		Object:     "document",
+		Collection: d.Collection,
+		Filename:   d.Filename,
+		MimeType:   d.MimeType,
+		Bytes:      d.Bytes,
+		Status:     string(d.Status),
+		Error:      d.Error,
+		Pages:      d.Pages,
+		OCRPages:   d.OCRPages,
+		Chunks:     d.Chunks,
+		CreatedAt:  d.CreatedAt.Unix(),
+	}
+}
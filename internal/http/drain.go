@@ -0,0 +1,254 @@
+package http
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// drainRetryAfter is the Retry-After, in seconds, of requests refused while
+// draining; by then the load balancer has taken the instance out of rotation
+const drainRetryAfter = "5"
+
+// drainPollInterval is how often a drain checks for requests still running
+const drainPollInterval = 100 * time.Millisecond
+
+// defaultDrainTimeout applies when server.drain_timeout is unset
+const defaultDrainTimeout = 30 * time.Second
+
+// DrainRequest is the optional body of POST /admin/drain
+type DrainRequest struct {
+	TimeoutSeconds int64 `json:"timeout_seconds,omitempty"` // Overrides server.drain_timeout
+}
+
+// DrainStatus reports the progress of a drain
+type DrainStatus struct {
+	Draining         bool       `json:"draining"`
+	Complete         bool       `json:"complete"`  // Nothing is left running
+	TimedOut         bool       `json:"timed_out"` // The deadline passed with requests still running
+	StartedAt        *time.Time `json:"started_at,omitempty"`
+	Deadline         *time.Time `json:"deadline,omitempty"`
+	InFlightRequests int64      `json:"in_flight_requests"` // Data-plane requests still running, streams included
+	ActiveStreams    int64      `json:"active_streams"`
+	QueuedRequests   int        `json:"queued_requests"` // Waiting in the dispatcher
+}
+
+// drainState counts in-flight data-plane requests so the server can stop
+// taking new ones and wait for the rest before shutting down
+type drainState struct {
+	requests atomic.Int64
+	streams  atomic.Int64
+
+	mu        sync.Mutex
+	draining  bool
+	startedAt time.Time
+	deadline  time.Time
+	done      chan struct{} // Closed when the current drain finished or timed out
+	timedOut  bool
+	cancel    context.CancelFunc
+}
+
+func (d *drainState) isDraining() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.draining
+}
+
+// isDataPlane reports whether a path serves inference traffic, which a drain
+// refuses. Health checks, metrics, the admin API and the dashboard stay up.
+func isDataPlane(path string) bool {
+	return strings.HasPrefix(path, "/v1/") || strings.HasPrefix(path, "/mcp")
+}
+
+// drainMiddleware refuses new data-plane requests while draining and counts
+// the ones in flight
+func (s *Server) drainMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isDataPlane(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if s.drain.isDraining() {
+			w.Header().Set("Retry-After", drainRetryAfter)
+			w.Header().Set("Connection", "close")
+			s.writeError(w, http.StatusServiceUnavailable, "server_draining", "The server is shutting down; retry the request")
+			return
+		}
+		s.drain.requests.Add(1)
+		defer s.drain.requests.Add(-1)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// trackStream counts an SSE stream until the returned func is called
+func (s *Server) trackStream() func() {
+	s.drain.streams.Add(1)
+	return func() { s.drain.streams.Add(-1) }
+}
+
+// StartDrain stops the server from accepting data-plane requests and waits in
+// the background, up to timeout, for the ones in flight and the dispatcher
+// queue to finish. A zero timeout uses server.drain_timeout. Starting a drain
+// that is already running leaves it as it is.
+func (s *Server) StartDrain(timeout time.Duration) DrainStatus {
+	if timeout <= 0 {
+		timeout = s.config.Load().Server.DrainTimeout
+	}
+	if timeout <= 0 {
+		timeout = defaultDrainTimeout
+	}
+
+	d := &s.drain
+	d.mu.Lock()
+	if !d.draining {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		d.draining = true
+		d.startedAt = time.Now()
+		d.deadline = d.startedAt.Add(timeout)
+		d.done = make(chan struct{})
+		d.timedOut = false
+		d.cancel = cancel
+		slog.Info("Draining: refusing new requests", "timeout", timeout,
+			"in_flight", d.requests.Load(), "streams", d.streams.Load())
+		go s.waitForDrain(ctx, d.done)
+	}
+	d.mu.Unlock()
+	return s.DrainStatus()
+}
+
+// Drain starts a drain and blocks until it completes, times out or ctx is done
+func (s *Server) Drain(ctx context.Context) DrainStatus {
+	s.StartDrain(0)
+	s.drain.mu.Lock()
+	done := s.drain.done
+	s.drain.mu.Unlock()
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+	return s.DrainStatus()
+}
+
+// ResumeTraffic cancels a drain, for a deploy that was called off
+func (s *Server) ResumeTraffic() {
+	d := &s.drain
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.draining {
+		return
+	}
+	d.cancel()
+	d.draining = false
+	slog.Info("Drain cancelled: accepting requests again")
+}
+
+func (s *Server) waitForDrain(ctx context.Context, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for {
+		if s.drain.requests.Load() == 0 && s.queuedRequests() == 0 {
+			slog.Info("Drain complete")
+			return
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			s.drain.mu.Lock()
+			// A cancelled drain isn't a timeout
+			s.drain.timedOut = s.drain.draining
+			s.drain.mu.Unlock()
+			if s.drain.isDraining() {
+				slog.Warn("Drain deadline passed with requests still running",
+					"in_flight", s.drain.requests.Load(), "streams", s.drain.streams.Load(), "queued", s.queuedRequests())
+			}
+			return
+		}
+	}
+}
+
+func (s *Server) queuedRequests() int {
+	if s.dispatcher == nil {
+		return 0
+	}
+	_, _, queued, _ := s.dispatcher.Capacity()
+	return queued
+}
+
+// DrainStatus reports the progress of the current drain
+func (s *Server) DrainStatus() DrainStatus {
+	d := &s.drain
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	status := DrainStatus{
+		Draining:         d.draining,
+		InFlightRequests: d.requests.Load(),
+		ActiveStreams:    d.streams.Load(),
+		QueuedRequests:   s.queuedRequests(),
+	}
+	if d.draining {
+		startedAt, deadline := d.startedAt, d.deadline
+		status.StartedAt, status.Deadline = &startedAt, &deadline
+		status.TimedOut = d.timedOut
+		status.Complete = status.InFlightRequests == 0 && status.QueuedRequests == 0
+	}
+	return status
+}
+
+// handleDrainStatus reports drain progress
+func (s *Server) handleDrainStatus(w http.ResponseWriter, r *http.Request) {
+	if !s.drainAdmin(w, r) {
+		return
+	}
+	s.writeJSON(w, http.StatusOK, s.DrainStatus())
+}
+
+// handleStartDrain starts a drain ahead of a deploy. It returns at once; poll
+// GET /admin/drain until complete.
+func (s *Server) handleStartDrain(w http.ResponseWriter, r *http.Request) {
+	if !s.drainAdmin(w, r) {
+		return
+	}
+	var body DrainRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			s.writeError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON body")
+			return
+		}
+	}
+	if body.TimeoutSeconds < 0 {
+		s.writeError(w, http.StatusBadRequest, "invalid_request", "timeout_seconds must be positive")
+		return
+	}
+	s.writeJSON(w, http.StatusAccepted, s.StartDrain(time.Duration(body.TimeoutSeconds)*time.Second))
+}
+
+// handleCancelDrain resumes traffic after a drain
+func (s *Server) handleCancelDrain(w http.ResponseWriter, r *http.Request) {
+	if !s.drainAdmin(w, r) {
+		return
+	}
+	s.ResumeTraffic()
+	s.writeJSON(w, http.StatusOK, s.DrainStatus())
+}
+
+// drainAdmin allows admin dashboard sessions and, for deploy hooks, the
+// server auth token. Otherwise it writes an error and returns false.
+func (s *Server) drainAdmin(w http.ResponseWriter, r *http.Request) bool {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if authToken := s.config.Load().Server.AuthToken; authToken != "" && token != "" &&
+		subtle.ConstantTimeCompare([]byte(token), []byte(authToken)) == 1 {
+		return true
+	}
+	if s.adminSession(r) == nil {
+		s.writeError(w, http.StatusForbidden, "access_denied", "Draining requires an admin session or the server auth token")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,74 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"modelgate/internal/config"
+)
+
+func TestDrain(t *testing.T) {
+	s := &Server{}
+	s.config.Store(config.Default())
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 2)
+	handler := s.drainMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// A request in flight when the drain starts is allowed to finish
+	inFlight := httptest.NewRecorder()
+	go handler.ServeHTTP(inFlight, httptest.NewRequest("POST", "/v1/chat/completions", nil))
+	<-started
+
+	status := s.StartDrain(time.Minute)
+	if !status.Draining || status.Complete || status.InFlightRequests != 1 {
+		t.Fatalf("unexpected status at the start of the drain %+v", status)
+	}
+
+	// New data-plane requests are refused; the rest of the API is not
+	refused := httptest.NewRecorder()
+	handler.ServeHTTP(refused, httptest.NewRequest("POST", "/v1/chat/completions", nil))
+	if refused.Code != http.StatusServiceUnavailable || refused.Header().Get("Retry-After") == "" {
+		t.Fatalf("expected 503 with Retry-After, got %d %v", refused.Code, refused.Header())
+	}
+	ready := httptest.NewRecorder()
+	s.handleReady(ready, httptest.NewRequest("GET", "/ready", nil))
+	if ready.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected /ready to fail while draining, got %d", ready.Code)
+	}
+
+	close(release)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if status := s.Drain(ctx); !status.Complete || status.TimedOut {
+		t.Fatalf("expected the drain to complete, got %+v", status)
+	}
+
+	// Cancelling the drain lets requests in again
+	s.ResumeTraffic()
+	resumed := httptest.NewRecorder()
+	handler.ServeHTTP(resumed, httptest.NewRequest("POST", "/v1/chat/completions", nil))
+	if resumed.Code != http.StatusOK {
+		t.Fatalf("expected requests to be served after resuming, got %d", resumed.Code)
+	}
+}
+
+func TestDrainTimesOut(t *testing.T) {
+	s := &Server{}
+	s.config.Store(config.Default())
+	s.drain.requests.Add(1) // A stream that never ends
+
+	s.StartDrain(50 * time.Millisecond)
+	if status := s.Drain(context.Background()); status.Complete || !status.Draining {
+		t.Fatalf("expected an incomplete drain, got %+v", status)
+	} else if !status.TimedOut {
+		t.Fatalf("expected the drain to time out, got %+v", status)
+	}
+}
@@ -0,0 +1,191 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"modelgate/internal/domain"
+	"modelgate/internal/feedback"
+	"modelgate/internal/storage/postgres"
+)
+
+// FeedbackRequest is the body of POST /v1/feedback. The response is named by
+// its chat completion ID or by its request ID.
+type FeedbackRequest struct {
+	ResponseID string `json:"response_id,omitempty"`
+	RequestID  string `json:"request_id,omitempty"`
+	Thumbs     string `json:"thumbs,omitempty"` // "up" or "down"
+	Rating     *int   `json:"rating,omitempty"` // 1 to 5
+	Comment    string `json:"comment,omitempty"`
+}
+
+// FeedbackResponse is the stored feedback
+type FeedbackResponse struct {
+	ID        string `json:"id"`
+	Object    string `json:"object"`
+	RequestID string `json:"request_id"`
+	Model     string `json:"model"`
+	Thumbs    string `json:"thumbs,omitempty"`
+	Rating    *int   `json:"rating,omitempty"`
+	Comment   string `json:"comment,omitempty"`
+	CreatedAt int64  `json:"created_at"`
+	UpdatedAt int64  `json:"updated_at"`
+}
+
+// SetFeedback enables response feedback in the API, the GraphQL API and the
+// admin export
+func (s *Server) SetFeedback(svc *feedback.Service) {
+	s.feedback = svc
+	if s.graphqlResolver != nil {
+		s.graphqlResolver.SetFeedback(svc)
+	}
+}
+
+// handleSubmitFeedback attaches feedback to a response made with the caller's
+// API key
+func (s *Server) handleSubmitFeedback(w http.ResponseWriter, r *http.Request, auth *AuthContext) {
+	if s.feedback == nil {
+		s.writeError(w, http.StatusNotFound, "not_found", "Feedback is not configured")
+		return
+	}
+
+	var body FeedbackRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		s.writeError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON body")
+		return
+	}
+	f := &domain.Feedback{RequestID: body.RequestID, Rating: body.Rating, Comment: body.Comment}
+	if body.ResponseID != "" {
+		f.RequestID = body.ResponseID
+	}
+	switch body.Thumbs {
+	case "":
+	case "up", "down":
+		up := body.Thumbs == "up"
+		f.ThumbsUp = &up
+	default:
+		s.writeError(w, http.StatusBadRequest, "invalid_request", `thumbs must be "up" or "down"`)
+		return
+	}
+
+	err := s.feedback.Submit(r.Context(), f, authAPIKeyID(auth))
+	switch {
+	case errors.Is(err, feedback.ErrNotFound):
+		s.writeError(w, http.StatusNotFound, "not_found", "No response with this ID was made with this API key")
+		return
+	case errors.Is(err, feedback.ErrInvalidFeedback):
+		s.writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	case err != nil:
+		slog.Error("Failed to save feedback", "request_id", f.RequestID, "error", err)
+		s.writeError(w, http.StatusInternalServerError, "server_error", "Failed to save feedback")
+		return
+	}
+
+	resp := FeedbackResponse{
+		ID:        f.ID,
+		Object:    "feedback",
+		RequestID: f.RequestID,
+		Model:     f.Model,
+		Rating:    f.Rating,
+		Comment:   f.Comment,
+		CreatedAt: f.CreatedAt.Unix(),
+		UpdatedAt: f.UpdatedAt.Unix(),
+	}
+	if f.ThumbsUp != nil {
+		resp.Thumbs = "down"
+		if *f.ThumbsUp {
+			resp.Thumbs = "up"
+		}
+	}
+	s.writeJSON(w, http.StatusCreated, resp)
+}
+
+// handleExportFeedback downloads the positively rated responses as a chat
+// fine-tuning JSONL file. Only admin dashboard sessions may export.
+func (s *Server) handleExportFeedback(w http.ResponseWriter, r *http.Request) {
+	user := s.adminSession(r)
+	if user == nil {
+		s.writeError(w, http.StatusForbidden, "access_denied", "Exporting feedback requires an admin session")
+		return
+	}
+	if s.feedback == nil {
+		s.writeError(w, http.StatusNotFound, "not_found", "Feedback is not configured")
+		return
+	}
+
+	query := r.URL.Query()
+	opts := feedback.ExportOptions{Model: query.Get("model")}
+	for name, dst := range map[string]*time.Time{"from": &opts.From, "to": &opts.To} {
+		if v := query.Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				s.writeError(w, http.StatusBadRequest, "invalid_request", fmt.Sprintf("invalid %s, use RFC3339", name))
+				return
+			}
+			*dst = t
+		}
+	}
+	if v := query.Get("min_rating"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 5 {
+			s.writeError(w, http.StatusBadRequest, "invalid_request", "min_rating must be between 1 and 5")
+			return
+		}
+		opts.MinRating = n
+	}
+
+	// Buffered so that a failure can still be reported as an error
+	var buf bytes.Buffer
+	report, err := s.feedback.Export(r.Context(), &buf, opts)
+	s.auditFeedbackExport(r, user, opts, report, err)
+	if err != nil {
+		slog.Error("Failed to export feedback", "error", err)
+		s.writeError(w, http.StatusInternalServerError, "server_error", "Failed to export feedback")
+		return
+	}
+
+	skipped := report.NotPositive + report.NotCaptured + report.NotTextual + report.Unreadable
+	w.Header().Set("Content-Type", "application/jsonl")
+	w.Header().Set("Content-Disposition", `attachment; filename="feedback-finetune.jsonl"`)
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Export-Examples", strconv.Itoa(report.Examples))
+	w.Header().Set("X-Export-Skipped", strconv.Itoa(skipped))
+	w.Header().Set("X-Export-Not-Captured", strconv.Itoa(report.NotCaptured))
+	w.Header().Set("X-Export-Truncated", strconv.FormatBool(report.Truncated))
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+// auditFeedbackExport records a feedback export in the audit log, since it
+// contains captured request content
+func (s *Server) auditFeedbackExport(r *http.Request, user *postgres.TenantUser, opts feedback.ExportOptions, report *feedback.ExportReport, err error) {
+	entry := &domain.AuditLog{
+		Action:       domain.AuditActionExport,
+		ResourceType: domain.AuditResourceFeedback,
+		ActorID:      user.ID,
+		ActorEmail:   user.Email,
+		ActorType:    "admin",
+		IPAddress:    clientIP(r, s.config.Load().Server.TrustedProxies).String(),
+		UserAgent:    r.UserAgent(),
+		Details:      map[string]any{"model": opts.Model, "min_rating": opts.MinRating},
+		Status:       "success",
+	}
+	if report != nil {
+		entry.Details["examples"] = report.Examples
+	}
+	if err != nil {
+		entry.Status = "failure"
+		entry.ErrorMessage = err.Error()
+	}
+	if err := s.pgStore.TenantStore().CreateAuditLog(context.WithoutCancel(r.Context()), entry); err != nil {
+		slog.Error("Failed to audit feedback export", "error", err)
+	}
+}
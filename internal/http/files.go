@@ -0,0 +1,480 @@
+package http
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"modelgate/internal/domain"
+	"modelgate/internal/files"
+)
+
+// CreateUploadRequest is the body for POST /v1/uploads
+type CreateUploadRequest struct {
+	Filename string `json:"filename"`
+	Purpose  string `json:"purpose"`
+	MimeType string `json:"mime_type,omitempty"`
+	Bytes    int64  `json:"bytes"`
+	SHA256   string `json:"sha256,omitempty"`
+}
+
+// CompleteUploadRequest is the body for POST /v1/uploads/{upload_id}/complete
+type CompleteUploadRequest struct {
+	PartIDs []int `json:"part_ids"`
+}
+
+// UploadResponse is the OpenAI-style upload object
+type UploadResponse struct {
+	ID        string          `json:"id"`
+	Object    string          `json:"object"` // "upload"
+	Bytes     int64           `json:"bytes"`
+	CreatedAt int64           `json:"created_at"`
+	Filename  string          `json:"filename"`
+	Purpose   string          `json:"purpose"`
+	Status    string          `json:"status"`
+	ExpiresAt int64           `json:"expires_at"`
+	Parts     []UploadPartRef `json:"parts,omitempty"`
+	File      *FileResponse   `json:"file,omitempty"`
+}
+
+// UploadPartRef describes a received part
+type UploadPartRef struct {
+	ID        int    `json:"id"` // Part number
+	Object    string `json:"object"`
+	UploadID  string `json:"upload_id"`
+	Bytes     int64  `json:"bytes"`
+	ETag      string `json:"etag"` // SHA-256 of the part
+	CreatedAt int64  `json:"created_at"`
+}
+
+// FileResponse is the OpenAI-style file object
+type FileResponse struct {
+	ID        string `json:"id"`
+	Object    string `json:"object"` // "file"
+	Bytes     int64  `json:"bytes"`
+	CreatedAt int64  `json:"created_at"`
+	Filename  string `json:"filename"`
+	Purpose   string `json:"purpose"`
+	Status    string `json:"status"`
+	SHA256    string `json:"sha256"`
+	ExpiresAt *int64 `json:"expires_at,omitempty"`
+	Provider  string `json:"provider,omitempty"` // Set when the file was passed through to a provider
+}
+
+// FileListResponse is the body of GET /v1/files
+type FileListResponse struct {
+	Object string          `json:"object"` // "list"
+	Data   []*FileResponse `json:"data"`
+}
+
+// FileDeletedResponse is the body of DELETE /v1/files/{file_id}
+type FileDeletedResponse struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"` // "file"
+	Deleted bool   `json:"deleted"`
+}
+
+// SetFileService enables the uploads endpoints
+func (s *Server) SetFileService(svc *files.Service) {
+	s.fileService = svc
+	s.mux = http.NewServeMux()
+	s.setupRoutes()
+}
+
+// handleCreateUpload handles POST /v1/uploads
+func (s *Server) handleCreateUpload(w http.ResponseWriter, r *http.Request, auth *AuthContext) {
+	var req CreateUploadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON body")
+		return
+	}
+
+	upload, err := s.fileService.CreateUpload(r.Context(), files.CreateUploadRequest{
+		Filename: req.Filename,
+		Purpose:  req.Purpose,
+		MimeType: req.MimeType,
+		Bytes:    req.Bytes,
+		SHA256:   req.SHA256,
+		APIKeyID: authAPIKeyID(auth),
+	})
+	if err != nil {
+		s.writeUploadError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, toUploadResponse(upload, nil, nil))
+}
+
+// handleGetUpload handles GET /v1/uploads/{upload_id}
+// The response lists received parts so an interrupted client can resume.
+func (s *Server) handleGetUpload(w http.ResponseWriter, r *http.Request, auth *AuthContext) {
+	upload, parts, err := s.fileService.GetUpload(r.Context(), r.PathValue("upload_id"))
+	if err == nil && !canAccessUpload(upload, auth) {
+		err = files.ErrUploadNotFound
+	}
+	if err != nil {
+		s.writeUploadError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, toUploadResponse(upload, parts, nil))
+}
+
+// handleUploadPart handles PUT /v1/uploads/{upload_id}/parts/{part_number}
+// The body is the raw part content. If X-Content-SHA256 is set, the part is
+// rejected when its checksum does not match.
+func (s *Server) handleUploadPart(w http.ResponseWriter, r *http.Request, auth *AuthContext) {
+	uploadID := r.PathValue("upload_id")
+	partNumber, err := strconv.Atoi(r.PathValue("part_number"))
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "invalid_request", "part_number must be an integer")
+		return
+	}
+
+	if !s.checkUploadAccess(w, r, uploadID, auth) {
+		return
+	}
+
+	part, err := s.fileService.PutPart(r.Context(), uploadID, partNumber, r.Body, r.Header.Get("X-Content-SHA256"))
+	if err != nil {
+		s.writeUploadError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", strconv.Quote(part.SHA256))
+	s.writeJSON(w, http.StatusOK, toPartRef(part))
+}
+
+// handleCompleteUpload handles POST /v1/uploads/{upload_id}/complete
+func (s *Server) handleCompleteUpload(w http.ResponseWriter, r *http.Request, auth *AuthContext) {
+	uploadID := r.PathValue("upload_id")
+
+	var req CompleteUploadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON body")
+		return
+	}
+
+	if !s.checkUploadAccess(w, r, uploadID, auth) {
+		return
+	}
+
+	file, err := s.fileService.Complete(r.Context(), uploadID, req.PartIDs)
+	if err != nil {
+		s.writeUploadError(w, err)
+		return
+	}
+
+	upload, parts, err := s.fileService.GetUpload(r.Context(), uploadID)
+	if err != nil {
+		s.writeUploadError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, toUploadResponse(upload, parts, file))
+}
+
+// handleCancelUpload handles POST /v1/uploads/{upload_id}/cancel
+func (s *Server) handleCancelUpload(w http.ResponseWriter, r *http.Request, auth *AuthContext) {
+	uploadID := r.PathValue("upload_id")
+	if !s.checkUploadAccess(w, r, uploadID, auth) {
+		return
+	}
+
+	upload, err := s.fileService.Cancel(r.Context(), uploadID)
+	if err != nil {
+		s.writeUploadError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, toUploadResponse(upload, nil, nil))
+}
+
+// handleUploadFile handles POST /v1/files
+// The multipart form carries the file, its purpose and, to pass the file
+// through to a provider's files API, the model it will be used with.
+func (s *Server) handleUploadFile(w http.ResponseWriter, r *http.Request, auth *AuthContext) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "invalid_request", "Expected a multipart/form-data body")
+		return
+	}
+
+	req := files.UploadFileRequest{APIKeyID: authAPIKeyID(auth), Policy: s.filePolicy(r, auth)}
+	// Fields are read in order; the file must come after purpose and model so
+	// it can be streamed without buffering the whole upload
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			s.writeError(w, http.StatusBadRequest, "invalid_request", "Invalid multipart body")
+			return
+		}
+		switch part.FormName() {
+		case "purpose", "model":
+			value, err := io.ReadAll(io.LimitReader(part, 1024))
+			if err != nil {
+				s.writeError(w, http.StatusBadRequest, "invalid_request", "Invalid multipart body")
+				return
+			}
+			if part.FormName() == "purpose" {
+				req.Purpose = string(value)
+			} else {
+				req.Model = string(value)
+			}
+		case "file":
+			req.Filename = part.FileName()
+			req.MimeType = part.Header.Get("Content-Type")
+			file, err := s.fileService.UploadFile(r.Context(), req, part)
+			if err != nil {
+				s.writeUploadError(w, err)
+				return
+			}
+			s.writeJSON(w, http.StatusOK, toFileResponse(file))
+			return
+		}
+		part.Close()
+	}
+	s.writeError(w, http.StatusBadRequest, "invalid_request", "file is required")
+}
+
+// handleListFiles handles GET /v1/files
+func (s *Server) handleListFiles(w http.ResponseWriter, r *http.Request, auth *AuthContext) {
+	limit := 100
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 10000 {
+			s.writeError(w, http.StatusBadRequest, "invalid_request", "limit must be between 1 and 10000")
+			return
+		}
+		limit = n
+	}
+
+	list, err := s.fileService.ListFiles(r.Context(), authAPIKeyID(auth), r.URL.Query().Get("purpose"), limit)
+	if err != nil {
+		s.writeUploadError(w, err)
+		return
+	}
+	resp := FileListResponse{Object: "list", Data: make([]*FileResponse, 0, len(list))}
+	for _, f := range list {
+		resp.Data = append(resp.Data, toFileResponse(f))
+	}
+	s.writeJSON(w, http.StatusOK, resp)
+}
+
+// handleDeleteFile handles DELETE /v1/files/{file_id}
+func (s *Server) handleDeleteFile(w http.ResponseWriter, r *http.Request, auth *AuthContext) {
+	fileID := r.PathValue("file_id")
+	file, err := s.fileService.GetFile(r.Context(), fileID)
+	if err == nil && (file == nil || !canAccessFile(file, auth)) {
+		err = files.ErrFileNotFound
+	}
+	if err == nil {
+		err = s.fileService.DeleteFile(r.Context(), fileID)
+	}
+	if err != nil {
+		s.writeUploadError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, FileDeletedResponse{ID: fileID, Object: "file", Deleted: true})
+}
+
+// handleGetFile handles GET /v1/files/{file_id}
+func (s *Server) handleGetFile(w http.ResponseWriter, r *http.Request, auth *AuthContext) {
+	file, err := s.fileService.GetFile(r.Context(), r.PathValue("file_id"))
+	if err == nil && (file == nil || !canAccessFile(file, auth)) {
+		err = files.ErrFileNotFound
+	}
+	if err != nil {
+		s.writeUploadError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, toFileResponse(file))
+}
+
+// handleGetFileContent handles GET /v1/files/{file_id}/content
+func (s *Server) handleGetFileContent(w http.ResponseWriter, r *http.Request, auth *AuthContext) {
+	file, content, err := s.fileService.OpenFile(r.Context(), r.PathValue("file_id"))
+	if err == nil && !canAccessFile(file, auth) {
+		content.Close()
+		err = files.ErrFileNotFound
+	}
+	if err != nil {
+		s.writeUploadError(w, err)
+		return
+	}
+	defer content.Close()
+
+	contentType := file.MimeType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(file.Bytes, 10))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", file.Filename))
+	if _, err := io.Copy(w, content); err != nil {
+		slog.Warn("Failed to stream file content", "file_id", file.ID, "error", err)
+	}
+}
+
+// checkUploadAccess writes a 404 and returns false if the caller cannot see the upload
+func (s *Server) checkUploadAccess(w http.ResponseWriter, r *http.Request, uploadID string, auth *AuthContext) bool {
+	upload, _, err := s.fileService.GetUpload(r.Context(), uploadID)
+	if err == nil && !canAccessUpload(upload, auth) {
+		err = files.ErrUploadNotFound
+	}
+	if err != nil {
+		s.writeUploadError(w, err)
+		return false
+	}
+	return true
+}
+
+// canAccessUpload reports whether an upload created by an API key is being
+// accessed by the same key. Session (admin) callers can access any upload.
+func canAccessUpload(upload *domain.Upload, auth *AuthContext) bool {
+	if upload.APIKeyID == "" || auth.APIKey == nil {
+		return true
+	}
+	return upload.APIKeyID == auth.APIKey.ID
+}
+
+// canAccessFile applies the same ownership rule to files
+func canAccessFile(file *domain.File, auth *AuthContext) bool {
+	if file.APIKeyID == "" || auth.APIKey == nil {
+		return true
+	}
+	return file.APIKeyID == auth.APIKey.ID
+}
+
+// filePolicy returns the file policy of the caller's role, or nil if there is none
+func (s *Server) filePolicy(r *http.Request, auth *AuthContext) *domain.FilePolicy {
+	if auth.APIKey == nil || auth.APIKey.RoleID == "" || s.pgStore == nil {
+		return nil
+	}
+	rolePolicy, err := s.pgStore.TenantStore().GetRolePolicy(r.Context(), auth.APIKey.RoleID)
+	if err != nil {
+		slog.Warn("Failed to load role policy for file upload", "role_id", auth.APIKey.RoleID, "error", err)
+		return nil
+	}
+	if rolePolicy == nil {
+		return nil
+	}
+	return &rolePolicy.FilePolicy
+}
+
+func authAPIKeyID(auth *AuthContext) string {
+	if auth.APIKey == nil {
+		return ""
+	}
+	return auth.APIKey.ID
+}
+
+// writeUploadError maps file service errors to HTTP responses
+func (s *Server) writeUploadError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, files.ErrUploadNotFound), errors.Is(err, files.ErrFileNotFound):
+		s.writeError(w, http.StatusNotFound, "not_found", err.Error())
+	case errors.Is(err, files.ErrUploadNotPending):
+		s.writeError(w, http.StatusConflict, "invalid_request", err.Error())
+	case errors.Is(err, files.ErrPartTooLarge), errors.Is(err, files.ErrFileTooLarge):
+		s.writeError(w, http.StatusRequestEntityTooLarge, "invalid_request", err.Error())
+	case errors.Is(err, files.ErrFileType):
+		s.writeError(w, http.StatusUnsupportedMediaType, "invalid_request", err.Error())
+	case errors.Is(err, files.ErrInvalidUpload), errors.Is(err, files.ErrChecksumMismatch),
+		errors.Is(err, files.ErrNoPassthrough), errors.Is(err, files.ErrFileUnusable):
+		s.writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+	default:
+		slog.Error("upload request failed", "error", err)
+		s.writeError(w, http.StatusInternalServerError, "server_error", fmt.Sprintf("upload failed: %v", err))
+	}
+}
+
+// parseFilePart converts an OpenAI file content part, referencing an uploaded
+// file by file_id or carrying it inline as a base64 data URL in file_data
+func parseFilePart(part map[string]any) (domain.ContentBlock, bool) {
+	f, ok := part["file"].(map[string]any)
+	if !ok {
+		return domain.ContentBlock{}, false
+	}
+	block := domain.ContentBlock{Type: "file"}
+	block.FileID, _ = f["file_id"].(string)
+	block.Filename, _ = f["filename"].(string)
+	if data, ok := f["file_data"].(string); ok && data != "" {
+		mediaType, encoded, ok := strings.Cut(strings.TrimPrefix(data, "data:"), ";base64,")
+		if !ok {
+			return domain.ContentBlock{}, false
+		}
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return domain.ContentBlock{}, false
+		}
+		block.MediaType = mediaType
+		block.FileData = decoded
+	}
+	if block.FileID == "" && block.FileData == nil {
+		return domain.ContentBlock{}, false
+	}
+	return block, true
+}
+
+func toUploadResponse(upload *domain.Upload, parts []*domain.UploadPart, file *domain.File) *UploadResponse {
+	resp := &UploadResponse{
+		ID:        upload.ID,
+		Object:    "upload",
+		Bytes:     upload.Bytes,
+		CreatedAt: upload.CreatedAt.Unix(),
+		Filename:  upload.Filename,
+		Purpose:   upload.Purpose,
+		Status:    string(upload.Status),
+		ExpiresAt: upload.ExpiresAt.Unix(),
+	}
+	for _, p := range parts {
+		resp.Parts = append(resp.Parts, toPartRef(p))
+	}
+	if file != nil {
+		resp.File = toFileResponse(file)
+	}
+	return resp
+}
+
+func toPartRef(p *domain.UploadPart) UploadPartRef {
+	return UploadPartRef{
+		ID:        p.PartNumber,
+		Object:    "upload.part",
+		UploadID:  p.UploadID,
+		Bytes:     p.Bytes,
+		ETag:      p.SHA256,
+		CreatedAt: p.CreatedAt.Unix(),
+	}
+}
+
+func toFileResponse(f *domain.File) *FileResponse {
+	createdAt := f.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	resp := &FileResponse{
+		ID:        f.ID,
+		Object:    "file",
+		Bytes:     f.Bytes,
+		CreatedAt: createdAt.Unix(),
+		Filename:  f.Filename,
+		Purpose:   f.Purpose,
+		Status:    f.Status,
+		SHA256:    f.SHA256,
+		Provider:  string(f.Provider),
+	}
+	if f.ExpiresAt != nil {
+		expiresAt := f.ExpiresAt.Unix()
+		resp.ExpiresAt = &expiresAt
+	}
+	return resp
+}
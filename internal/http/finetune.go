@@ -0,0 +1,200 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"modelgate/internal/domain"
+	"modelgate/internal/finetune"
+)
+
+// CreateFineTuneJobRequest is the body for POST /v1/fine_tuning/jobs
+type CreateFineTuneJobRequest struct {
+	Model           string         `json:"model"`
+	TrainingFile    string         `json:"training_file"`
+	ValidationFile  string         `json:"validation_file,omitempty"`
+	Hyperparameters map[string]any `json:"hyperparameters,omitempty"`
+	Suffix          string         `json:"suffix,omitempty"`
+	Metadata        map[string]any `json:"metadata,omitempty"`
+}
+
+// FineTuneJobResponse is the OpenAI-style fine_tuning.job object
+type FineTuneJobResponse struct {
+	ID              string            `json:"id"`
+	Object          string            `json:"object"` // "fine_tuning.job"
+	Model           string            `json:"model"`
+	Provider        string            `json:"provider"`
+	Status          string            `json:"status"`
+	TrainingFile    string            `json:"training_file"`
+	ValidationFile  *string           `json:"validation_file"`
+	Hyperparameters map[string]any    `json:"hyperparameters"`
+	Suffix          *string           `json:"suffix"`
+	FineTunedModel  *string           `json:"fine_tuned_model"`
+	TrainedTokens   *int64            `json:"trained_tokens"`
+	Error           *FineTuneJobError `json:"error"`
+	Metadata        map[string]any    `json:"metadata,omitempty"`
+	CreatedAt       int64             `json:"created_at"`
+	FinishedAt      *int64            `json:"finished_at"`
+}
+
+// FineTuneJobError describes why a fine-tuning job failed
+type FineTuneJobError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// SetFineTuneService enables the fine-tuning endpoints
+func (s *Server) SetFineTuneService(svc *finetune.Service) {
+	s.fineTuneService = svc
+	s.mux = http.NewServeMux()
+	s.setupRoutes()
+}
+
+// handleCreateFineTuneJob handles POST /v1/fine_tuning/jobs
+func (s *Server) handleCreateFineTuneJob(w http.ResponseWriter, r *http.Request, auth *AuthContext) {
+	if !s.requirePermission(w, r, auth, domain.PermissionFineTuneWrite) {
+		return
+	}
+	var req CreateFineTuneJobRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON body")
+		return
+	}
+
+	job, err := s.fineTuneService.Create(r.Context(), finetune.CreateRequest{
+		Model:            s.config.Load().ResolveModel(req.Model),
+		TrainingFileID:   req.TrainingFile,
+		ValidationFileID: req.ValidationFile,
+		Hyperparameters:  req.Hyperparameters,
+		Suffix:           req.Suffix,
+		Metadata:         req.Metadata,
+	}, s.fineTuneActor(r, auth))
+	if err != nil {
+		s.writeFineTuneError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, toFineTuneJobResponse(job))
+}
+
+// handleListFineTuneJobs handles GET /v1/fine_tuning/jobs
+func (s *Server) handleListFineTuneJobs(w http.ResponseWriter, r *http.Request, auth *AuthContext) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	jobs, err := s.fineTuneService.List(r.Context(), authAPIKeyID(auth), limit)
+	if err != nil {
+		s.writeFineTuneError(w, err)
+		return
+	}
+
+	data := make([]*FineTuneJobResponse, len(jobs))
+	for i, job := range jobs {
+		data[i] = toFineTuneJobResponse(job)
+	}
+	s.writeJSON(w, http.StatusOK, map[string]any{
+		"object":   "list",
+		"data":     data,
+		"has_more": false,
+	})
+}
+
+// handleGetFineTuneJob handles GET /v1/fine_tuning/jobs/{job_id}
+func (s *Server) handleGetFineTuneJob(w http.ResponseWriter, r *http.Request, auth *AuthContext) {
+	job, err := s.fineTuneService.Get(r.Context(), r.PathValue("job_id"))
+	if err == nil && !canAccessFineTuneJob(job, auth) {
+		err = finetune.ErrJobNotFound
+	}
+	if err != nil {
+		s.writeFineTuneError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, toFineTuneJobResponse(job))
+}
+
+// handleCancelFineTuneJob handles POST /v1/fine_tuning/jobs/{job_id}/cancel
+func (s *Server) handleCancelFineTuneJob(w http.ResponseWriter, r *http.Request, auth *AuthContext) {
+	if !s.requirePermission(w, r, auth, domain.PermissionFineTuneWrite) {
+		return
+	}
+	jobID := r.PathValue("job_id")
+	job, err := s.fineTuneService.Get(r.Context(), jobID)
+	if err == nil && !canAccessFineTuneJob(job, auth) {
+		err = finetune.ErrJobNotFound
+	}
+	if err == nil {
+		job, err = s.fineTuneService.Cancel(r.Context(), jobID, s.fineTuneActor(r, auth))
+	}
+	if err != nil {
+		s.writeFineTuneError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, toFineTuneJobResponse(job))
+}
+
+// fineTuneActor identifies the caller for the fine-tuning audit log
+func (s *Server) fineTuneActor(r *http.Request, auth *AuthContext) finetune.Actor {
+	actor := finetune.Actor{APIKeyID: authAPIKeyID(auth), UserAgent: r.UserAgent()}
+	if ip := clientIP(r, s.config.Load().Server.TrustedProxies); ip.IsValid() {
+		actor.IPAddress = ip.String()
+	}
+	return actor
+}
+
+// canAccessFineTuneJob applies the same ownership rule as batches
+func canAccessFineTuneJob(job *domain.FineTuneJob, auth *AuthContext) bool {
+	if job.APIKeyID == "" || auth.APIKey == nil {
+		return true
+	}
+	return job.APIKeyID == auth.APIKey.ID
+}
+
+// writeFineTuneError maps fine-tuning service errors to HTTP responses
+func (s *Server) writeFineTuneError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, finetune.ErrJobNotFound):
+		s.writeError(w, http.StatusNotFound, "not_found", err.Error())
+	case errors.Is(err, finetune.ErrJobNotCancellable):
+		s.writeError(w, http.StatusConflict, "invalid_request", err.Error())
+	case errors.Is(err, finetune.ErrInvalidJob), errors.Is(err, finetune.ErrNotSupported):
+		s.writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+	default:
+		slog.Error("fine-tuning request failed", "error", err)
+		s.writeError(w, http.StatusInternalServerError, "server_error", fmt.Sprintf("fine-tuning request failed: %v", err))
+	}
+}
+
+func toFineTuneJobResponse(job *domain.FineTuneJob) *FineTuneJobResponse {
+	resp := &FineTuneJobResponse{
+		ID:              job.ID,
+		Object:          "fine_tuning.job",
+		Model:           job.Model,
+		Provider:        string(job.Provider),
+		Status:          string(job.Status),
+		TrainingFile:    job.TrainingFileID,
+		Hyperparameters: job.Hyperparameters,
+		Metadata:        job.Metadata,
+		CreatedAt:       job.CreatedAt.Unix(),
+	}
+	if job.ValidationFileID != "" {
+		resp.ValidationFile = &job.ValidationFileID
+	}
+	if job.Suffix != "" {
+		resp.Suffix = &job.Suffix
+	}
+	if job.FineTunedModel != "" {
+		resp.FineTunedModel = &job.FineTunedModel
+	}
+	if job.TrainedTokens > 0 {
+		resp.TrainedTokens = &job.TrainedTokens
+	}
+	if job.Error != "" {
+		resp.Error = &FineTuneJobError{Code: "provider_error", Message: job.Error}
+	}
+	if job.FinishedAt != nil {
+		finishedAt := job.FinishedAt.Unix()
+		resp.FinishedAt = &finishedAt
+	}
+	return resp
+}
@@ -0,0 +1,204 @@
+package http
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+	"modelgate/internal/graphql/resolver"
+
+	"github.com/99designs/gqlgen/complexity"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Error codes of operations refused by the GraphQL limits
+const (
+	errCodeDepthLimit         = "DEPTH_LIMIT_EXCEEDED"
+	errCodeComplexityLimit    = "COMPLEXITY_LIMIT_EXCEEDED"
+	errCodePersistedQueryOnly = "PERSISTED_QUERY_REQUIRED"
+	errCodeGraphQLRateLimited = "RATE_LIMITED"
+)
+
+// graphQLLimits is a gqlgen extension enforcing [graphql]: it resolves
+// persisted queries, rejects operations nested or costing more than allowed
+// and turns introspection on or off. Limits are read per operation, so
+// config reloads apply to the next one.
+type graphQLLimits struct {
+	config    func() config.GraphQLConfig
+	persisted map[string]string // SHA-256 of a query to the query
+	schema    graphql.ExecutableSchema
+}
+
+var (
+	_ graphql.HandlerExtension          = (*graphQLLimits)(nil)
+	_ graphql.OperationParameterMutator = (*graphQLLimits)(nil)
+	_ graphql.OperationContextMutator   = (*graphQLLimits)(nil)
+)
+
+func (l *graphQLLimits) ExtensionName() string {
+	return "GraphQLLimits"
+}
+
+func (l *graphQLLimits) Validate(schema graphql.ExecutableSchema) error {
+	l.schema = schema
+	return nil
+}
+
+// MutateOperationParameters fills in queries sent by the hash of a persisted
+// query, and refuses other queries when only persisted ones are allowed
+func (l *graphQLLimits) MutateOperationParameters(ctx context.Context, params *graphql.RawParams) *gqlerror.Error {
+	if params.Query == "" {
+		if ext, ok := params.Extensions["persistedQuery"].(map[string]any); ok {
+			hash, _ := ext["sha256Hash"].(string)
+			if query, ok := l.persisted[strings.ToLower(hash)]; ok {
+				params.Query = query
+			}
+		}
+		return nil // Unknown hashes are left to automatic persisted queries
+	}
+	if l.config().PersistedQueriesOnly {
+		if _, ok := l.persisted[queryHash(params.Query)]; !ok {
+			err := gqlerror.Errorf("only persisted queries are accepted")
+			errcode.Set(err, errCodePersistedQueryOnly)
+			return err
+		}
+	}
+	return nil
+}
+
+// MutateOperationContext applies the introspection setting and the depth and
+// complexity limits to a parsed operation
+func (l *graphQLLimits) MutateOperationContext(ctx context.Context, opCtx *graphql.OperationContext) *gqlerror.Error {
+	cfg := l.config()
+	opCtx.DisableIntrospection = !cfg.Introspection
+	if opCtx.Operation == nil {
+		return nil
+	}
+
+	if cfg.MaxDepth > 0 {
+		if depth := selectionDepth(opCtx.Operation.SelectionSet); depth > cfg.MaxDepth {
+			err := gqlerror.Errorf("operation has depth %d, which exceeds the limit of %d", depth, cfg.MaxDepth)
+			errcode.Set(err, errCodeDepthLimit)
+			return err
+		}
+	}
+	if cfg.MaxComplexity > 0 && l.schema != nil {
+		if cost := complexity.Calculate(ctx, l.schema, opCtx.Operation, opCtx.Variables); cost > cfg.MaxComplexity {
+			err := gqlerror.Errorf("operation has complexity %d, which exceeds the limit of %d", cost, cfg.MaxComplexity)
+			errcode.Set(err, errCodeComplexityLimit)
+			return err
+		}
+	}
+	return nil
+}
+
+// selectionDepth returns how deeply fields are nested in a selection set,
+// through fragments. Introspection fields don't count; the introspection
+// setting governs them.
+func selectionDepth(set ast.SelectionSet) int {
+	depth := 0
+	for _, sel := range set {
+		var d int
+		switch sel := sel.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(sel.Name, "__") {
+				continue
+			}
+			d = 1 + selectionDepth(sel.SelectionSet)
+		case *ast.InlineFragment:
+			d = selectionDepth(sel.SelectionSet)
+		case *ast.FragmentSpread:
+			if sel.Definition != nil {
+				d = selectionDepth(sel.Definition.SelectionSet)
+			}
+		}
+		depth = max(depth, d)
+	}
+	return depth
+}
+
+func queryHash(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
+
+// loadPersistedQueries reads a JSON object of SHA-256 hashes to queries,
+// checking that each hash is its query's
+func loadPersistedQueries(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var listed map[string]string
+	if err := json.Unmarshal(data, &listed); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	queries := make(map[string]string, len(listed))
+	for hash, query := range listed {
+		if !strings.EqualFold(hash, queryHash(query)) {
+			return nil, fmt.Errorf("%s: %s is not the SHA-256 hash of its query", path, hash)
+		}
+		queries[queryHash(query)] = query
+	}
+	return queries, nil
+}
+
+// withGraphQLRateLimit limits each signed-in user, or each client address for
+// requests without a valid session, to [graphql] rate_limit_per_minute
+// requests. It runs after withGraphQLAuth, so only validated sessions get a
+// budget of their own; bogus tokens share their address's.
+func (s *Server) withGraphQLRateLimit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cfg := s.config.Load()
+		limit := cfg.GraphQL.RateLimitPerMinute
+		if limit <= 0 || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var caller string
+		if user, ok := r.Context().Value(resolver.ContextKeyUser).(*domain.User); ok && user != nil {
+			caller = "user:" + user.ID
+		} else {
+			caller = "ip:" + clientIP(r, cfg.Server.TrustedProxies).String()
+		}
+		result := s.graphqlLimiter.ConsumeRequest("graphql:"+caller, limit, 0)
+		if !result.Allowed {
+			retryAfter := max(1, int(time.Until(result.Window.Reset).Seconds()+0.999))
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusTooManyRequests)
+			err := gqlerror.Errorf("rate limit of %d GraphQL requests per minute exceeded; retry in %d seconds", limit, retryAfter)
+			errcode.Set(err, errCodeGraphQLRateLimited)
+			if encErr := json.NewEncoder(w).Encode(graphql.Response{Errors: gqlerror.List{err}}); encErr != nil {
+				slog.Debug("writing GraphQL rate limit response failed", "error", encErr)
+			}
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// playgroundHandler serves the GraphQL playground while introspection is on
+func (s *Server) playgroundHandler(playground http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.config.Load().GraphQL.Introspection {
+			http.NotFound(w, r)
+			return
+		}
+		playground.ServeHTTP(w, r)
+	})
+}
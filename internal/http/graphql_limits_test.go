@@ -0,0 +1,178 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+	"modelgate/internal/graphql/resolver"
+	"modelgate/internal/policy"
+)
+
+// graphQLRequest posts a GraphQL body to the server's /graphql route and
+// returns the status and the error codes of the response
+func graphQLRequest(t *testing.T, s *Server, token, body string) (int, []string) {
+	t.Helper()
+	r := httptest.NewRequest("POST", "/graphql", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	s.mux.ServeHTTP(w, r)
+
+	var resp struct {
+		Errors []struct {
+			Extensions struct {
+				Code string `json:"code"`
+			} `json:"extensions"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid GraphQL response %q: %v", w.Body.String(), err)
+	}
+	var codes []string
+	for _, e := range resp.Errors {
+		codes = append(codes, e.Extensions.Code)
+	}
+	return w.Code, codes
+}
+
+func newGraphQLServer(t *testing.T, cfg *config.Config) *Server {
+	t.Helper()
+	s := &Server{mux: http.NewServeMux()}
+	s.config.Store(cfg)
+	s.initGraphQL()
+	s.setupRoutes()
+	return s
+}
+
+func TestGraphQLLimits(t *testing.T) {
+	cfg := config.Default()
+	cfg.GraphQL.MaxDepth = 2
+	cfg.GraphQL.MaxComplexity = 3
+	s := newGraphQLServer(t, cfg)
+
+	if code, errs := graphQLRequest(t, s, "", `{"query": "{ __typename }"}`); code != http.StatusOK || len(errs) != 0 {
+		t.Fatalf("expected a plain query to pass, got %d %v", code, errs)
+	}
+	if _, errs := graphQLRequest(t, s, "", `{"query": "{ roles { policy { id } } }"}`); len(errs) != 1 || errs[0] != errCodeDepthLimit {
+		t.Fatalf("expected the depth limit, got %v", errs)
+	}
+	if _, errs := graphQLRequest(t, s, "", `{"query": "{ roles { id name description } }"}`); len(errs) != 1 || errs[0] != errCodeComplexityLimit {
+		t.Fatalf("expected the complexity limit, got %v", errs)
+	}
+	// Fragments count towards the depth
+	query := `{"query": "query { ...R } fragment R on Query { roles { policy { id } } }"}`
+	if _, errs := graphQLRequest(t, s, "", query); len(errs) != 1 || errs[0] != errCodeDepthLimit {
+		t.Fatalf("expected the depth limit through a fragment, got %v", errs)
+	}
+
+	// Introspection and the playground follow the live config
+	if _, errs := graphQLRequest(t, s, "", `{"query": "{ __schema { queryType { name } } }"}`); len(errs) != 0 {
+		t.Fatalf("expected introspection to be allowed, got %v", errs)
+	}
+	off := *cfg
+	off.GraphQL.Introspection = false
+	s.config.Store(&off)
+	if _, errs := graphQLRequest(t, s, "", `{"query": "{ __schema { queryType { name } } }"}`); len(errs) != 1 {
+		t.Fatal("expected introspection to be refused")
+	}
+	w := httptest.NewRecorder()
+	s.mux.ServeHTTP(w, httptest.NewRequest("GET", "/playground", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected the playground to be hidden, got %d", w.Code)
+	}
+}
+
+func TestGraphQLPersistedQueries(t *testing.T) {
+	listed := "{ __typename }"
+	path := filepath.Join(t.TempDir(), "queries.json")
+	manifest, _ := json.Marshal(map[string]string{strings.ToUpper(queryHash(listed)): listed})
+	if err := os.WriteFile(path, manifest, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.Default()
+	cfg.GraphQL.PersistedQueriesFile = path
+	cfg.GraphQL.PersistedQueriesOnly = true
+	s := newGraphQLServer(t, cfg)
+
+	byHash := `{"extensions": {"persistedQuery": {"version": 1, "sha256Hash": "` + queryHash(listed) + `"}}}`
+	if code, errs := graphQLRequest(t, s, "", byHash); code != http.StatusOK || len(errs) != 0 {
+		t.Fatalf("expected the persisted query to run by hash, got %d %v", code, errs)
+	}
+	if _, errs := graphQLRequest(t, s, "", `{"query": "{ __typename }"}`); len(errs) != 0 {
+		t.Fatalf("expected the listed query to be accepted, got %v", errs)
+	}
+	if _, errs := graphQLRequest(t, s, "", `{"query": "{ __typename __typename }"}`); len(errs) != 1 || errs[0] != errCodePersistedQueryOnly {
+		t.Fatalf("expected an unlisted query to be refused, got %v", errs)
+	}
+
+	os.WriteFile(path, []byte(`{"0000": "{ __typename }"}`), 0o600)
+	if _, err := loadPersistedQueries(path); err == nil {
+		t.Fatal("expected a wrong hash to be rejected")
+	}
+}
+
+func TestGraphQLRateLimit(t *testing.T) {
+	cfg := config.Default()
+	cfg.GraphQL.RateLimitPerMinute = 2
+	s := newGraphQLServer(t, cfg)
+
+	for i := 0; i < 2; i++ {
+		if code, _ := graphQLRequest(t, s, "", `{"query": "{ __typename }"}`); code != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d", i, code)
+		}
+	}
+	code, errs := graphQLRequest(t, s, "", `{"query": "{ __typename }"}`)
+	if code != http.StatusTooManyRequests || len(errs) != 1 || errs[0] != errCodeGraphQLRateLimited {
+		t.Fatalf("expected the third request to be limited, got %d %v", code, errs)
+	}
+	// Tokens that aren't valid sessions share their address's budget, so
+	// rotating them doesn't get around the limit
+	for _, token := range []string{"bogus-1", "bogus-2", "bogus-3"} {
+		if code, _ := graphQLRequest(t, s, token, `{"query": "{ __typename }"}`); code != http.StatusTooManyRequests {
+			t.Fatalf("expected bogus token %s to be limited, got %d", token, code)
+		}
+	}
+}
+
+func TestGraphQLRateLimitPerUser(t *testing.T) {
+	cfg := config.Default()
+	cfg.GraphQL.RateLimitPerMinute = 1
+	s := &Server{graphqlLimiter: policy.NewRateLimiter()}
+	s.config.Store(cfg)
+	h := s.withGraphQLRateLimit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	send := func(user *domain.User) int {
+		r := httptest.NewRequest("POST", "/graphql", nil)
+		if user != nil {
+			r = r.WithContext(context.WithValue(r.Context(), resolver.ContextKeyUser, user))
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	alice, bob := &domain.User{ID: "alice"}, &domain.User{ID: "bob"}
+	if code := send(alice); code != http.StatusOK {
+		t.Fatalf("expected alice's first request to pass, got %d", code)
+	}
+	if code := send(alice); code != http.StatusTooManyRequests {
+		t.Fatalf("expected alice's second request to be limited, got %d", code)
+	}
+	// Signed-in users and anonymous callers each have their own budget
+	if code := send(bob); code != http.StatusOK {
+		t.Fatalf("expected bob to pass, got %d", code)
+	}
+	if code := send(nil); code != http.StatusOK {
+		t.Fatalf("expected an anonymous caller to pass, got %d", code)
+	}
+}
@@ -0,0 +1,175 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"modelgate/internal/domain"
+	"modelgate/internal/idempotency"
+)
+
+// SetIdempotency enables Idempotency-Key handling on chat completions and responses
+func (s *Server) SetIdempotency(svc *idempotency.Service) {
+	s.idempotency = svc
+}
+
+// withIdempotency makes a handler honour the Idempotency-Key header. The first
+// request with a key runs and, if it succeeds, its response is stored; a
+// repeat with the same body gets the stored response back, one sent while
+// the first is still running is rejected with 409.
+func (s *Server) withIdempotency(next func(http.ResponseWriter, *http.Request, *AuthContext)) func(http.ResponseWriter, *http.Request, *AuthContext) {
+	return func(w http.ResponseWriter, r *http.Request, auth *AuthContext) {
+		key := r.Header.Get("Idempotency-Key")
+		if key == "" || s.idempotency == nil || auth == nil || auth.APIKey == nil {
+			next(w, r, auth)
+			return
+		}
+		cfg := s.idempotency.Config()
+		if !cfg.Enabled {
+			next(w, r, auth)
+			return
+		}
+		if len(key) > idempotency.MaxKeyLength {
+			s.writeError(w, http.StatusBadRequest, "invalid_request_error",
+				"Idempotency-Key must be at most "+strconv.Itoa(idempotency.MaxKeyLength)+" characters")
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			s.writeError(w, http.StatusBadRequest, "invalid_request_error", "Failed to read request body")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		hash := requestHash(r.URL.Path, body)
+
+		ctx := r.Context()
+		stored, err := s.idempotency.Begin(ctx, auth.APIKey.ID, key, hash)
+		switch {
+		case errors.Is(err, idempotency.ErrInProgress):
+			s.writeError(w, http.StatusConflict, "idempotency_conflict", err.Error())
+			return
+		case errors.Is(err, idempotency.ErrKeyReused):
+			s.writeError(w, http.StatusUnprocessableEntity, "idempotency_key_reused", err.Error())
+			return
+		case err != nil:
+			// Fail open: an unavailable store shouldn't take chat down with it
+			slog.Error("Idempotency check failed, handling request without it", "error", err)
+			next(w, r, auth)
+			return
+		case stored != nil:
+			replayIdempotent(w, stored)
+			return
+		}
+
+		capture := &captureWriter{ResponseWriter: w, limit: cfg.MaxResponseBytes}
+		next(capture, r, auth)
+
+		// A cancelled request may have been cut short, so it isn't kept
+		if capture.status() < 200 || capture.status() >= 300 || capture.overflow || ctx.Err() != nil {
+			s.idempotency.Release(context.WithoutCancel(ctx), auth.APIKey.ID, key, hash)
+			return
+		}
+		s.idempotency.Complete(context.WithoutCancel(ctx), &domain.IdempotencyRecord{
+			APIKeyID:    auth.APIKey.ID,
+			Key:         key,
+			RequestHash: hash,
+			StatusCode:  capture.status(),
+			Header:      replayHeaders(w.Header()),
+			Body:        capture.body.Bytes(),
+		})
+	}
+}
+
+// requestHash identifies a request by endpoint and body, so a key reused for
+// a different request can be told apart from a retry
+func requestHash(path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(path))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// replayHeaders picks the response headers worth replaying: the content type
+// and ModelGate's own headers (request ID, routing, policy results)
+func replayHeaders(h http.Header) map[string]string {
+	out := make(map[string]string)
+	for name, values := range h {
+		if len(values) == 0 {
+			continue
+		}
+		if name == "Content-Type" || strings.HasPrefix(name, "X-Modelgate-") {
+			out[name] = values[0]
+		}
+	}
+	return out
+}
+
+// replayIdempotent writes a stored response
+func replayIdempotent(w http.ResponseWriter, rec *domain.IdempotencyRecord) {
+	for name, value := range rec.Header {
+		w.Header().Set(name, value)
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(rec.StatusCode)
+	w.Write(rec.Body)
+}
+
+// captureWriter passes a response through while keeping a copy of it, up to
+// limit bytes. Streaming handlers still flush through it.
+type captureWriter struct {
+	http.ResponseWriter
+	code     int
+	body     bytes.Buffer
+	limit    int
+	overflow bool
+}
+
+func (c *captureWriter) WriteHeader(code int) {
+	if c.code == 0 {
+		c.code = code
+	}
+	c.ResponseWriter.WriteHeader(code)
+}
+
+func (c *captureWriter) Write(p []byte) (int, error) {
+	if c.code == 0 {
+		c.code = http.StatusOK
+	}
+	if !c.overflow {
+		if c.limit > 0 && c.body.Len()+len(p) > c.limit {
+			c.overflow = true
+			c.body.Reset()
+		} else {
+			c.body.Write(p)
+		}
+	}
+	return c.ResponseWriter.Write(p)
+}
+
+func (c *captureWriter) Flush() {
+	if f, ok := c.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (c *captureWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
+
+func (c *captureWriter) status() int {
+	if c.code == 0 {
+		return http.StatusOK
+	}
+	return c.code
+}
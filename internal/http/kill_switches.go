@@ -0,0 +1,155 @@
+package http
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"modelgate/internal/domain"
+	"modelgate/internal/killswitch"
+	"modelgate/internal/storage/postgres"
+)
+
+// KillSwitchRequest is the body of POST /admin/kill-switches
+type KillSwitchRequest struct {
+	Provider         string     `json:"provider"`
+	Model            string     `json:"model,omitempty"` // Stop a single model instead of the whole provider
+	Reason           string     `json:"reason"`
+	ExpiresInSeconds int64      `json:"expires_in_seconds,omitempty"` // Re-enable automatically after this long
+	ExpiresAt        *time.Time `json:"expires_at,omitempty"`         // Or at this time
+}
+
+// KillSwitchResponse is a switch in effect
+type KillSwitchResponse struct {
+	Provider   string     `json:"provider"`
+	Model      string     `json:"model,omitempty"`
+	Target     string     `json:"target"`
+	Reason     string     `json:"reason"`
+	DisabledBy string     `json:"disabled_by,omitempty"`
+	DisabledAt time.Time  `json:"disabled_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+}
+
+// SetKillSwitch enables kill switches in the GraphQL API and the admin endpoints
+func (s *Server) SetKillSwitch(svc *killswitch.Service) {
+	s.killSwitch = svc
+	if s.graphqlResolver != nil {
+		s.graphqlResolver.SetKillSwitch(svc)
+	}
+}
+
+// handleListKillSwitches lists the switches in effect
+func (s *Server) handleListKillSwitches(w http.ResponseWriter, r *http.Request) {
+	if s.killSwitchAdmin(w, r) == nil {
+		return
+	}
+	switches, err := s.killSwitch.List(r.Context())
+	if err != nil {
+		slog.Error("Failed to list kill switches", "error", err)
+		s.writeError(w, http.StatusInternalServerError, "server_error", "Failed to list kill switches")
+		return
+	}
+	resp := make([]KillSwitchResponse, len(switches))
+	for i, ks := range switches {
+		resp[i] = killSwitchResponse(ks)
+	}
+	s.writeJSON(w, http.StatusOK, map[string]any{"data": resp})
+}
+
+// handleDisableTraffic stops traffic to a provider or model
+func (s *Server) handleDisableTraffic(w http.ResponseWriter, r *http.Request) {
+	user := s.killSwitchAdmin(w, r)
+	if user == nil {
+		return
+	}
+
+	var body KillSwitchRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		s.writeError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON body")
+		return
+	}
+	if body.Provider == "" || strings.TrimSpace(body.Reason) == "" {
+		s.writeError(w, http.StatusBadRequest, "invalid_request", "provider and reason are required")
+		return
+	}
+	if body.ExpiresInSeconds < 0 {
+		s.writeError(w, http.StatusBadRequest, "invalid_request", "expires_in_seconds must be positive")
+		return
+	}
+
+	ks := domain.KillSwitch{
+		Provider:  domain.Provider(strings.ToLower(body.Provider)),
+		Model:     body.Model,
+		Reason:    body.Reason,
+		ExpiresAt: body.ExpiresAt,
+	}
+	if body.ExpiresInSeconds > 0 {
+		expiresAt := time.Now().Add(time.Duration(body.ExpiresInSeconds) * time.Second)
+		ks.ExpiresAt = &expiresAt
+	}
+	saved, err := s.killSwitch.Disable(r.Context(), ks, s.killSwitchActor(r, user))
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	s.writeJSON(w, http.StatusCreated, killSwitchResponse(saved))
+}
+
+// handleEnableTraffic clears the switch on a provider, or on ?model= of it
+func (s *Server) handleEnableTraffic(w http.ResponseWriter, r *http.Request) {
+	user := s.killSwitchAdmin(w, r)
+	if user == nil {
+		return
+	}
+
+	provider := domain.Provider(strings.ToLower(r.PathValue("provider")))
+	cleared, err := s.killSwitch.Enable(r.Context(), provider, r.URL.Query().Get("model"), s.killSwitchActor(r, user))
+	if err != nil {
+		slog.Error("Failed to clear kill switch", "provider", provider, "error", err)
+		s.writeError(w, http.StatusInternalServerError, "server_error", "Failed to clear kill switch")
+		return
+	}
+	if !cleared {
+		s.writeError(w, http.StatusNotFound, "not_found", "No kill switch is set for this provider or model")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// killSwitchAdmin returns the admin behind the request, or writes an error
+// and returns nil
+func (s *Server) killSwitchAdmin(w http.ResponseWriter, r *http.Request) *postgres.TenantUser {
+	user := s.adminSession(r)
+	if user == nil {
+		s.writeError(w, http.StatusForbidden, "access_denied", "Kill switches require an admin session")
+		return nil
+	}
+	if s.killSwitch == nil {
+		s.writeError(w, http.StatusNotFound, "not_found", "Kill switches are not configured")
+		return nil
+	}
+	return user
+}
+
+func (s *Server) killSwitchActor(r *http.Request, user *postgres.TenantUser) killswitch.Actor {
+	return killswitch.Actor{
+		ID:        user.ID,
+		Email:     user.Email,
+		IPAddress: clientIP(r, s.config.Load().Server.TrustedProxies).String(),
+		UserAgent: r.UserAgent(),
+	}
+}
+
+func killSwitchResponse(ks *domain.KillSwitch) KillSwitchResponse {
+	return KillSwitchResponse{
+		Provider:   string(ks.Provider),
+		Model:      ks.Model,
+		Target:     ks.Target(),
+		Reason:     ks.Reason,
+		DisabledBy: ks.DisabledBy,
+		DisabledAt: ks.DisabledAt,
+		ExpiresAt:  ks.ExpiresAt,
+	}
+}
@@ -0,0 +1,116 @@
+package http
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"modelgate/internal/domain"
+)
+
+// parseModelFilter reads the catalog filters of GET /v1/models:
+//
+//	provider          Provider name, e.g. openai
+//	capabilities      Comma-separated capabilities the model must all have: tools, vision, reasoning
+//	min_context       Smallest context window, in tokens
+//	max_input_price   Highest input price in USD per 1M tokens
+//	max_output_price  Highest output price in USD per 1M tokens
+//
+// It returns nil when none is set.
+func parseModelFilter(q url.Values) (*domain.ModelFilter, error) {
+	var filter domain.ModelFilter
+	set := false
+
+	if v := strings.TrimSpace(q.Get("provider")); v != "" {
+		p, ok := domain.ParseProvider(strings.ToLower(v))
+		if !ok {
+			return nil, fmt.Errorf("unknown provider %q", v)
+		}
+		filter.Provider = p
+		set = true
+	}
+	for _, c := range strings.Split(q.Get("capabilities"), ",") {
+		switch strings.ToLower(strings.TrimSpace(c)) {
+		case "":
+			continue
+		case "tools":
+			filter.SupportsTools = true
+		case "vision":
+			filter.SupportsVision = true
+		case "reasoning":
+			filter.SupportsReasoning = true
+		default:
+			return nil, fmt.Errorf("unknown capability %q: expected tools, vision or reasoning", c)
+		}
+		set = true
+	}
+	if v := strings.TrimSpace(q.Get("min_context")); v != "" {
+		n, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid min_context %q: expected a number of tokens", v)
+		}
+		filter.MinContext = uint32(n)
+		set = true
+	}
+	for _, price := range []struct {
+		param string
+		dst   **float64
+	}{
+		{"max_input_price", &filter.MaxInputCost},
+		{"max_output_price", &filter.MaxOutputCost},
+	} {
+		v := strings.TrimSpace(q.Get(price.param))
+		if v == "" {
+			continue
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f < 0 || math.IsInf(f, 0) || math.IsNaN(f) {
+			return nil, fmt.Errorf("invalid %s %q: expected a price in USD per 1M tokens", price.param, v)
+		}
+		*price.dst = &f
+		set = true
+	}
+
+	if !set {
+		return nil, nil
+	}
+	return &filter, nil
+}
+
+// modelDetail converts a described model to its catalog entry
+func modelDetail(m domain.ModelInfo) ModelDetail {
+	capabilities := []string{}
+	if m.SupportsTools {
+		capabilities = append(capabilities, "tools")
+	}
+	if m.SupportsVision {
+		capabilities = append(capabilities, "vision")
+	}
+	if m.SupportsReasoning {
+		capabilities = append(capabilities, "reasoning")
+	}
+	name := m.Name
+	if name == "" {
+		name = m.ID
+	}
+	return ModelDetail{
+		ModelData: ModelData{
+			ID:      m.ID,
+			Object:  "model",
+			Created: 1234567890,
+			OwnedBy: string(m.Provider),
+		},
+		Name:            name,
+		Provider:        string(m.Provider),
+		Capabilities:    capabilities,
+		ContextWindow:   m.ContextLimit,
+		MaxOutputTokens: m.OutputLimit,
+		Pricing: ModelPricing{
+			InputPer1M:       m.InputCostPer1M,
+			OutputPer1M:      m.OutputCostPer1M,
+			CachedInputPer1M: m.CachedInputCostPer1M,
+		},
+	}
+}
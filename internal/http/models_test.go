@@ -0,0 +1,67 @@
+package http
+
+import (
+	"net/url"
+	"testing"
+
+	"modelgate/internal/domain"
+)
+
+func TestModelCatalogFilter(t *testing.T) {
+	models := []domain.ModelInfo{
+		{ID: "gpt-4o", Provider: domain.ProviderOpenAI, SupportsTools: true, SupportsVision: true, ContextLimit: 128000, InputCostPer1M: 2.5, OutputCostPer1M: 10},
+		{ID: "o3-mini", Provider: domain.ProviderOpenAI, SupportsTools: true, SupportsReasoning: true, ContextLimit: 200000, InputCostPer1M: 1.1, OutputCostPer1M: 4.4},
+		{ID: "claude-3-haiku", Provider: domain.ProviderAnthropic, SupportsTools: true, SupportsVision: true, ContextLimit: 200000, InputCostPer1M: 0.25, OutputCostPer1M: 1.25},
+		{ID: "llama3", Provider: domain.ProviderOllama, ContextLimit: 8192},
+	}
+	match := func(query string) []string {
+		t.Helper()
+		q, _ := url.ParseQuery(query)
+		filter, err := parseModelFilter(q)
+		if err != nil {
+			t.Fatalf("%s: %v", query, err)
+		}
+		var ids []string
+		for _, m := range models {
+			if filter == nil || filter.Matches(m) {
+				ids = append(ids, m.ID)
+			}
+		}
+		return ids
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"gpt-4o", "o3-mini", "claude-3-haiku", "llama3"}},
+		{"provider=OpenAI", []string{"gpt-4o", "o3-mini"}},
+		{"capabilities=tools,vision", []string{"gpt-4o", "claude-3-haiku"}},
+		{"capabilities=reasoning&min_context=150000", []string{"o3-mini"}},
+		{"min_context=100000&max_input_price=1.5", []string{"o3-mini", "claude-3-haiku"}},
+		{"max_output_price=0", []string{"llama3"}},
+	}
+	for _, tt := range tests {
+		got := match(tt.query)
+		if len(got) != len(tt.want) {
+			t.Fatalf("%q: got %v, want %v", tt.query, got, tt.want)
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Fatalf("%q: got %v, want %v", tt.query, got, tt.want)
+			}
+		}
+	}
+
+	for _, bad := range []string{"provider=nobody", "capabilities=telepathy", "min_context=-1", "max_input_price=cheap", "max_output_price=-2"} {
+		q, _ := url.ParseQuery(bad)
+		if _, err := parseModelFilter(q); err == nil {
+			t.Fatalf("expected %q to be rejected", bad)
+		}
+	}
+
+	detail := modelDetail(models[0])
+	if detail.Name != "gpt-4o" || detail.Object != "model" || len(detail.Capabilities) != 2 || detail.Pricing.OutputPer1M != 10 || detail.ContextWindow != 128000 {
+		t.Fatalf("unexpected detail %+v", detail)
+	}
+}
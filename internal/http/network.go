@@ -0,0 +1,178 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"strings"
+
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+)
+
+// tlsConfig builds the listener TLS settings, or returns nil for plain HTTP
+func tlsConfig(cfg config.ServerConfig) (*tls.Config, error) {
+	if cfg.TLSCertFile == "" {
+		return nil, nil
+	}
+	cert, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("loading TLS certificate: %w", err)
+	}
+	tc := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	switch cfg.ClientAuth {
+	case "optional":
+		tc.ClientAuth = tls.VerifyClientCertIfGiven
+	case "require":
+		tc.ClientAuth = tls.RequireAndVerifyClientCert
+	default:
+		return tc, nil
+	}
+	pem, err := os.ReadFile(cfg.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading client CA bundle: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in client CA bundle %s", cfg.ClientCAFile)
+	}
+	tc.ClientCAs = pool
+	return tc, nil
+}
+
+// clientIP resolves the address of the calling client. X-Forwarded-For is only
+// honoured when the direct peer is a trusted proxy; the rightmost untrusted hop
+// is the client, so entries a client adds itself are ignored.
+func clientIP(r *http.Request, trustedProxies []string) netip.Addr {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	peer, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}
+	}
+	peer = peer.Unmap()
+	if !domain.CIDRsContain(trustedProxies, peer) {
+		return peer
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		hop = hop.Unmap()
+		if !domain.CIDRsContain(trustedProxies, hop) {
+			return hop
+		}
+		peer = hop
+	}
+	return peer
+}
+
+// hasVerifiedClientCert reports whether the connection presented a client
+// certificate that chained to the configured client CA
+func hasVerifiedClientCert(r *http.Request) bool {
+	return r.TLS != nil && len(r.TLS.VerifiedChains) > 0
+}
+
+// checkNetworkAccess applies the API key's IP allowlist and the network
+// policies of its role and its group's roles. It returns an empty string when
+// the request may proceed, otherwise the reason it was denied.
+func (s *Server) checkNetworkAccess(ctx context.Context, r *http.Request, auth *AuthContext, ip netip.Addr) string {
+	key := auth.APIKey
+	if len(key.AllowedCIDRs) > 0 && !domain.CIDRsContain(key.AllowedCIDRs, ip) {
+		return "client IP is not in the API key's allowlist"
+	}
+
+	if s.pgStore == nil || (key.RoleID == "" && key.GroupID == "") {
+		return ""
+	}
+	rolePolicies, loadErrors := s.authRolePolicies(ctx, auth)
+	if len(loadErrors) > 0 {
+		slog.Warn("Failed to load role policies for network check", "api_key_id", key.ID, "errors", loadErrors)
+	}
+	return networkPolicyDenial(r, ip, rolePolicies, loadErrors)
+}
+
+// networkPolicyDenial applies role network policies: every role that enables
+// one must admit the request. When a role's policy couldn't be loaded the
+// request is denied, since that policy may have refused it.
+func networkPolicyDenial(r *http.Request, ip netip.Addr, rolePolicies []*domain.RolePolicy, loadErrors []string) string {
+	if len(loadErrors) > 0 {
+		return "role network policy could not be loaded"
+	}
+	for _, rolePolicy := range rolePolicies {
+		if rolePolicy == nil || !rolePolicy.NetworkPolicy.Enabled {
+			continue
+		}
+		np := rolePolicy.NetworkPolicy
+		if len(np.AllowedCIDRs) > 0 && !domain.CIDRsContain(np.AllowedCIDRs, ip) {
+			return "client IP is not in the role's allowlist"
+		}
+		if np.RequireClientCert && !hasVerifiedClientCert(r) {
+			return "role requires a verified client certificate"
+		}
+	}
+	return ""
+}
+
+// mcpNetworkCheck applies checkNetworkAccess to /mcp requests, auditing the
+// requests it denies
+func (s *Server) mcpNetworkCheck(r *http.Request, key *domain.APIKey) string {
+	ip := clientIP(r, s.config.Load().Server.TrustedProxies)
+	reason := s.checkNetworkAccess(r.Context(), r, &AuthContext{APIKey: key}, ip)
+	if reason != "" {
+		s.auditNetworkDenial(r, key, ip, reason)
+	}
+	return reason
+}
+
+// denyNetworkAccess records a network policy violation in the audit log and
+// rejects the request with 403
+func (s *Server) denyNetworkAccess(w http.ResponseWriter, r *http.Request, key *domain.APIKey, ip netip.Addr, reason string) {
+	s.auditNetworkDenial(r, key, ip, reason)
+	s.writeError(w, http.StatusForbidden, "access_denied", "Request not permitted from this network: "+reason)
+}
+
+// auditNetworkDenial records a network policy violation in the audit log
+func (s *Server) auditNetworkDenial(r *http.Request, key *domain.APIKey, ip netip.Addr, reason string) {
+	slog.Warn("Network policy denied request", "api_key_id", key.ID, "client_ip", ip, "reason", reason)
+
+	if s.pgStore != nil {
+		entry := &domain.AuditLog{
+			Action:       domain.AuditActionAccessDenied,
+			ResourceType: domain.AuditResourceAPIKey,
+			ResourceID:   key.ID,
+			ResourceName: key.Name,
+			ActorID:      key.ID,
+			ActorType:    "api_key",
+			IPAddress:    ip.String(),
+			UserAgent:    r.UserAgent(),
+			Details: map[string]any{
+				"method":      r.Method,
+				"path":        r.URL.Path,
+				"role_id":     key.RoleID,
+				"group_id":    key.GroupID,
+				"client_cert": hasVerifiedClientCert(r),
+			},
+			Status:       "failure",
+			ErrorMessage: reason,
+		}
+		if err := s.pgStore.TenantStore().CreateAuditLog(context.WithoutCancel(r.Context()), entry); err != nil {
+			slog.Error("Failed to audit network policy violation", "error", err)
+		}
+	}
+}
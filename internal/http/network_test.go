@@ -0,0 +1,129 @@
+package http
+
+import (
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+	"modelgate/internal/storage/postgres"
+)
+
+func TestClientIPOnlyTrustsForwardedForFromProxies(t *testing.T) {
+	trusted := []string{"10.0.0.0/8"}
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		want       string
+	}{
+		{"direct client", "203.0.113.7:5000", "", "203.0.113.7"},
+		{"spoofed header from untrusted peer", "203.0.113.7:5000", "192.0.2.1", "203.0.113.7"},
+		{"via trusted proxy", "10.1.2.3:443", "198.51.100.4", "198.51.100.4"},
+		{"client-supplied hop is ignored", "10.1.2.3:443", "192.0.2.1, 198.51.100.4, 10.9.9.9", "198.51.100.4"},
+		{"ipv4-mapped peer", "[::ffff:203.0.113.7]:5000", "", "203.0.113.7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/v1/models", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			if got := clientIP(r, trusted); got.String() != tt.want {
+				t.Fatalf("clientIP = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPIKeyAllowlist(t *testing.T) {
+	s := &Server{}
+	key := &domain.APIKey{ID: "k1", AllowedCIDRs: []string{"198.51.100.0/24", "2001:db8::1"}}
+	r := httptest.NewRequest("POST", "/v1/chat/completions", nil)
+
+	for ip, allowed := range map[string]bool{
+		"198.51.100.20": true,
+		"2001:db8::1":   true,
+		"203.0.113.7":   false,
+	} {
+		reason := s.checkNetworkAccess(r.Context(), r, &AuthContext{APIKey: key}, netip.MustParseAddr(ip))
+		if (reason == "") != allowed {
+			t.Errorf("%s: allowed = %v, reason %q", ip, allowed, reason)
+		}
+	}
+
+	if _, err := domain.NormalizeCIDRs([]string{"10.0.0.0/33"}); err == nil {
+		t.Fatal("expected an invalid CIDR to be rejected")
+	}
+}
+
+func TestRoleNetworkPolicies(t *testing.T) {
+	office := &domain.RolePolicy{NetworkPolicy: domain.NetworkPolicy{Enabled: true, AllowedCIDRs: []string{"198.51.100.0/24"}}}
+	vpn := &domain.RolePolicy{NetworkPolicy: domain.NetworkPolicy{Enabled: true, AllowedCIDRs: []string{"198.51.100.0/28"}}}
+	mtls := &domain.RolePolicy{NetworkPolicy: domain.NetworkPolicy{Enabled: true, RequireClientCert: true}}
+	open := &domain.RolePolicy{}
+	r := httptest.NewRequest("POST", "/v1/chat/completions", nil)
+
+	tests := []struct {
+		name       string
+		ip         string
+		policies   []*domain.RolePolicy
+		loadErrors []string
+		allowed    bool
+	}{
+		{"role without a network policy", "203.0.113.7", []*domain.RolePolicy{open}, nil, true},
+		{"inside the role's allowlist", "198.51.100.20", []*domain.RolePolicy{office}, nil, true},
+		{"outside the role's allowlist", "203.0.113.7", []*domain.RolePolicy{office}, nil, false},
+		{"every role must admit the request", "198.51.100.20", []*domain.RolePolicy{open, office, vpn}, nil, false},
+		{"group role requiring a client certificate", "198.51.100.1", []*domain.RolePolicy{office, mtls}, nil, false},
+		{"policy that failed to load", "198.51.100.20", []*domain.RolePolicy{office}, []string{"failed to load group roles for g1"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason := networkPolicyDenial(r, netip.MustParseAddr(tt.ip), tt.policies, tt.loadErrors)
+			if (reason == "") != tt.allowed {
+				t.Fatalf("allowed = %v, reason %q", tt.allowed, reason)
+			}
+		})
+	}
+}
+
+func TestMCPNetworkCheck(t *testing.T) {
+	s := &Server{}
+	s.config.Store(config.Default())
+	key := &domain.APIKey{ID: "k1", AllowedCIDRs: []string{"198.51.100.0/24"}}
+
+	r := httptest.NewRequest("POST", "/mcp", nil)
+	r.RemoteAddr = "198.51.100.20:5000"
+	if reason := s.mcpNetworkCheck(r, key); reason != "" {
+		t.Fatalf("expected an allowlisted address to pass, got %q", reason)
+	}
+	r.RemoteAddr = "203.0.113.7:5000"
+	if reason := s.mcpNetworkCheck(r, key); reason == "" {
+		t.Fatal("expected an address outside the allowlist to be denied on /mcp")
+	}
+}
+
+func TestNetworkCheckReusesLoadedPolicies(t *testing.T) {
+	// The store has no database: loading the policies again would panic
+	s := &Server{pgStore: &postgres.Store{}}
+	office := &domain.RolePolicy{NetworkPolicy: domain.NetworkPolicy{Enabled: true, AllowedCIDRs: []string{"198.51.100.0/24"}}}
+	auth := &AuthContext{
+		APIKey:         &domain.APIKey{ID: "k1", RoleID: "r1"},
+		policiesLoaded: true,
+		policies:       []*domain.RolePolicy{office},
+	}
+	r := httptest.NewRequest("POST", "/v1/chat/completions", nil)
+
+	if reason := s.checkNetworkAccess(r.Context(), r, auth, netip.MustParseAddr("198.51.100.20")); reason != "" {
+		t.Fatalf("expected the loaded policy to admit the request, got %q", reason)
+	}
+	if reason := s.checkNetworkAccess(r.Context(), r, auth, netip.MustParseAddr("203.0.113.7")); reason == "" {
+		t.Fatal("expected the loaded policy to deny the request")
+	}
+	if policies, _ := s.authRolePolicies(r.Context(), auth); len(policies) != 1 || policies[0] != office {
+		t.Fatalf("expected the policies loaded for the request, got %v", policies)
+	}
+}
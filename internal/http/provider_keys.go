@@ -0,0 +1,65 @@
+package http
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"modelgate/internal/provider"
+)
+
+// ProviderKeyValidationResponse is the result of POST /admin/provider-keys/{id}/validate
+type ProviderKeyValidationResponse struct {
+	KeyID        string    `json:"key_id"`
+	Valid        bool      `json:"valid"`
+	Method       string    `json:"method"`
+	Model        string    `json:"model,omitempty"`
+	LatencyMs    int       `json:"latency_ms"`
+	ModelCount   int       `json:"model_count,omitempty"`
+	Capabilities []string  `json:"capabilities"`
+	ErrorCode    string    `json:"error_code,omitempty"`
+	Error        string    `json:"error,omitempty"`
+	CheckedAt    time.Time `json:"checked_at"`
+}
+
+// handleValidateProviderKey tests a stored provider key with a cheap provider
+// call and records the result on the key. An invalid key is still a 200; the
+// body says why it failed.
+func (s *Server) handleValidateProviderKey(w http.ResponseWriter, r *http.Request) {
+	if s.adminSession(r) == nil {
+		s.writeError(w, http.StatusForbidden, "access_denied", "Validating provider keys requires an admin session")
+		return
+	}
+
+	keyID := r.PathValue("id")
+	result, err := s.gateway.ValidateProviderKey(r.Context(), "default", keyID)
+	if err != nil {
+		if errors.Is(err, provider.ErrKeyNotFound) {
+			s.writeError(w, http.StatusNotFound, "not_found", err.Error())
+			return
+		}
+		slog.Error("Failed to validate provider key", "key_id", keyID, "error", err)
+		s.writeError(w, http.StatusInternalServerError, "server_error", "Failed to validate provider key")
+		return
+	}
+
+	resp := ProviderKeyValidationResponse{
+		KeyID:        keyID,
+		Valid:        result.Valid,
+		Method:       result.Method,
+		Model:        result.Model,
+		LatencyMs:    result.LatencyMs,
+		ModelCount:   result.ModelCount,
+		Capabilities: result.Capabilities,
+		CheckedAt:    result.CheckedAt,
+	}
+	if resp.Capabilities == nil {
+		resp.Capabilities = []string{}
+	}
+	if result.Error != nil {
+		resp.ErrorCode = result.Error.Code
+		resp.Error = result.Error.Message
+	}
+	s.writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,236 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"modelgate/internal/domain"
+	"modelgate/internal/gateway"
+
+	"github.com/google/uuid"
+)
+
+// queueEventsHeader opts a streaming request into "queued" SSE events. They
+// are named events, which OpenAI SDKs don't expect, so they are off by default.
+const queueEventsHeader = "X-ModelGate-Queue-Events"
+
+// asyncResultTTL is how long the result of a Prefer: respond-async request
+// stays available for polling once it finished
+const asyncResultTTL = 10 * time.Minute
+
+// queueStatusResponse is the queue status sent in "queued" events and by
+// GET /v1/queue/{id}
+type queueStatusResponse struct {
+	ID              string `json:"id,omitempty"`
+	Object          string `json:"object"`
+	Status          string `json:"status"` // queued or in_progress
+	Position        int    `json:"position,omitempty"`
+	Queued          int    `json:"queued,omitempty"`
+	EstimatedWaitMs int64  `json:"estimated_wait_ms,omitempty"`
+}
+
+func newQueueStatusResponse(id string, status gateway.QueueStatus) queueStatusResponse {
+	return queueStatusResponse{
+		ID:              id,
+		Object:          "queue.status",
+		Status:          "queued",
+		Position:        status.Position,
+		Queued:          status.Queued,
+		EstimatedWaitMs: status.EstimatedWait.Milliseconds(),
+	}
+}
+
+// queueEvents writes "queued" events to a stream while its request waits in
+// the dispatcher queue. The first event starts the SSE response.
+type queueEvents struct {
+	w       http.ResponseWriter
+	started bool
+}
+
+func (q *queueEvents) send(status gateway.QueueStatus) {
+	if !q.started {
+		q.w.Header().Set("Content-Type", "text/event-stream")
+		q.w.Header().Set("Cache-Control", "no-cache")
+		q.w.Header().Set("Connection", "keep-alive")
+		q.started = true
+	}
+	data, _ := json.Marshal(newQueueStatusResponse("", status))
+	if _, err := fmt.Fprintf(q.w, "event: queued\ndata: %s\n\n", data); err == nil {
+		http.NewResponseController(q.w).Flush()
+	}
+}
+
+// errorWriter returns where an error response goes: the response itself, or
+// an SSE data event once the stream has started
+func (q *queueEvents) errorWriter() http.ResponseWriter {
+	if !q.started {
+		return q.w
+	}
+	return &sseErrorWriter{w: q.w, header: http.Header{}}
+}
+
+// sseErrorWriter sends a JSON error body as the last event of a stream whose
+// status and headers were already sent
+type sseErrorWriter struct {
+	w      http.ResponseWriter
+	header http.Header
+}
+
+func (e *sseErrorWriter) Header() http.Header { return e.header }
+
+func (e *sseErrorWriter) WriteHeader(int) {}
+
+func (e *sseErrorWriter) Write(p []byte) (int, error) {
+	if _, err := fmt.Fprintf(e.w, "data: %s\n\ndata: [DONE]\n\n", bytes.TrimSpace(p)); err != nil {
+		return 0, err
+	}
+	http.NewResponseController(e.w).Flush()
+	return len(p), nil
+}
+
+// preferRespondAsync reports whether the client asked for a 202 and a status
+// URL instead of waiting for the completion (RFC 7240)
+func preferRespondAsync(r *http.Request) bool {
+	for _, value := range r.Header.Values("Prefer") {
+		for _, pref := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(pref), "respond-async") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// asyncRequests holds the Prefer: respond-async requests of this instance.
+// They live in memory, so polling has to reach the instance that accepted them.
+type asyncRequests struct {
+	mu   sync.Mutex
+	jobs map[string]*asyncRequest
+}
+
+// asyncRequest is an accepted request and, once done, its recorded response
+type asyncRequest struct {
+	apiKeyID string
+	req      *gateway.DispatchRequest
+	done     chan struct{}
+	doneAt   time.Time
+
+	header http.Header
+	code   int
+	body   bytes.Buffer
+}
+
+func (a *asyncRequest) Header() http.Header { return a.header }
+
+func (a *asyncRequest) WriteHeader(code int) {
+	if a.code == 0 {
+		a.code = code
+	}
+}
+
+func (a *asyncRequest) Write(p []byte) (int, error) {
+	if a.code == 0 {
+		a.code = http.StatusOK
+	}
+	return a.body.Write(p)
+}
+
+func (a *asyncRequest) isDone() bool {
+	select {
+	case <-a.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// add stores a job, dropping finished ones older than asyncResultTTL
+func (a *asyncRequests) add(id string, job *asyncRequest) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.jobs == nil {
+		a.jobs = make(map[string]*asyncRequest)
+	}
+	for jobID, j := range a.jobs {
+		if j.isDone() && time.Since(j.doneAt) > asyncResultTTL {
+			delete(a.jobs, jobID)
+		}
+	}
+	a.jobs[id] = job
+}
+
+func (a *asyncRequests) get(id string) *asyncRequest {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.jobs[id]
+}
+
+func (a *asyncRequests) finish(job *asyncRequest) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	job.doneAt = time.Now()
+	close(job.done)
+}
+
+// acceptAsyncRequest answers 202 with a status URL and runs the request in the
+// background. It keeps counting as in flight so a drain waits for it.
+func (s *Server) acceptAsyncRequest(w http.ResponseWriter, r *http.Request, dispatchReq *gateway.DispatchRequest, domainReq *domain.ChatRequest, req *ChatCompletionRequest) {
+	ctx := context.WithoutCancel(r.Context())
+	dispatchReq.Ctx = ctx
+
+	id := "queue-" + uuid.New().String()
+	job := &asyncRequest{
+		apiKeyID: domainReq.APIKeyID,
+		req:      dispatchReq,
+		done:     make(chan struct{}),
+		header:   http.Header{},
+	}
+	s.asyncRequests.add(id, job)
+	requestAuditFrom(r.Context()).detach()
+	requestAttestationFrom(r.Context()).detach()
+
+	s.drain.requests.Add(1)
+	go func() {
+		defer s.drain.requests.Add(-1)
+		defer s.asyncRequests.finish(job)
+		s.dispatchChatCompletion(job, r.WithContext(ctx), dispatchReq, domainReq, req)
+	}()
+
+	w.Header().Set("Location", "/v1/queue/"+id)
+	w.Header().Set("Retry-After", "1")
+	w.Header().Set("Preference-Applied", "respond-async")
+	s.writeJSON(w, http.StatusAccepted, queueStatusResponse{ID: id, Object: "queue.status", Status: "queued"})
+}
+
+// handleGetQueueStatus handles GET /v1/queue/{id}: the queue status of an
+// async request, or its completion once done
+func (s *Server) handleGetQueueStatus(w http.ResponseWriter, r *http.Request, auth *AuthContext) {
+	id := r.PathValue("id")
+	job := s.asyncRequests.get(id)
+	if job == nil || auth.APIKey == nil || job.apiKeyID != auth.APIKey.ID {
+		s.writeError(w, http.StatusNotFound, "not_found", "Queued request not found")
+		return
+	}
+
+	if job.isDone() {
+		for name, values := range job.header {
+			w.Header()[name] = values
+		}
+		w.WriteHeader(job.code)
+		w.Write(job.body.Bytes())
+		return
+	}
+
+	w.Header().Set("Retry-After", "1")
+	if status, ok := s.dispatcher.QueueStatus(job.req); ok {
+		s.writeJSON(w, http.StatusAccepted, newQueueStatusResponse(id, status))
+		return
+	}
+	s.writeJSON(w, http.StatusAccepted, queueStatusResponse{ID: id, Object: "queue.status", Status: "in_progress"})
+}
@@ -0,0 +1,44 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"modelgate/internal/gateway"
+)
+
+func TestQueueEventsAndErrors(t *testing.T) {
+	s := &Server{}
+
+	// Before any event, errors are ordinary JSON responses
+	w := httptest.NewRecorder()
+	events := &queueEvents{w: w}
+	s.writeError(events.errorWriter(), http.StatusServiceUnavailable, "queue_timeout", "timed out")
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected a 503 before the stream started, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	events = &queueEvents{w: w}
+	events.send(gateway.QueueStatus{Position: 2, Queued: 5, EstimatedWait: 1500 * time.Millisecond})
+	s.writeError(events.errorWriter(), http.StatusServiceUnavailable, "queue_timeout", "timed out")
+
+	want := "event: queued\ndata: {\"object\":\"queue.status\",\"status\":\"queued\",\"position\":2,\"queued\":5,\"estimated_wait_ms\":1500}\n\n" +
+		"data: {\"error\":{\"type\":\"queue_timeout\",\"message\":\"timed out\"}}\n\ndata: [DONE]\n\n"
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/event-stream" || w.Body.String() != want {
+		t.Fatalf("unexpected stream %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestPreferRespondAsync(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	if preferRespondAsync(r) {
+		t.Fatal("expected no preference without a Prefer header")
+	}
+	r.Header.Set("Prefer", "return=minimal, Respond-Async")
+	if !preferRespondAsync(r) {
+		t.Fatal("expected respond-async among several preferences to be found")
+	}
+}
@@ -0,0 +1,61 @@
+package http
+
+import (
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+func TestSetRetryAfter(t *testing.T) {
+	now := time.Now()
+	soon := &domain.RateLimitWindow{Limit: 10, Remaining: 0, Reset: now.Add(10 * time.Second)}
+	later := &domain.RateLimitWindow{Limit: 1000, Remaining: 400, Reset: now.Add(50 * time.Second)}
+	exhaustedLater := &domain.RateLimitWindow{Limit: 1000, Remaining: 0, Reset: now.Add(50 * time.Second)}
+
+	tests := []struct {
+		name  string
+		state *domain.RateLimitState
+		min   int
+		max   int
+	}{
+		{
+			name:  "window with capacity left is ignored",
+			state: &domain.RateLimitState{Requests: soon, Tokens: later},
+			min:   9, max: 10,
+		},
+		{
+			name:  "exceeded window",
+			state: &domain.RateLimitState{Requests: soon, Exceeded: soon},
+			min:   9, max: 10,
+		},
+		{
+			// A token request can exceed a window that still has tokens left
+			name:  "exceeded window with capacity left",
+			state: &domain.RateLimitState{Requests: soon, Tokens: later, Exceeded: later},
+			min:   49, max: 50,
+		},
+		{
+			name:  "last of the exhausted windows",
+			state: &domain.RateLimitState{Requests: soon, Tokens: exhaustedLater},
+			min:   49, max: 50,
+		},
+		{
+			name:  "at least a second",
+			state: &domain.RateLimitState{Requests: &domain.RateLimitWindow{Reset: now.Add(-time.Second)}},
+			min:   1, max: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			setRetryAfter(w, tt.state)
+			got, err := strconv.Atoi(w.Header().Get("Retry-After"))
+			if err != nil || got < tt.min || got > tt.max {
+				t.Fatalf("Retry-After = %q, want %d-%d", w.Header().Get("Retry-After"), tt.min, tt.max)
+			}
+		})
+	}
+}
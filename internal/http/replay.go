@@ -0,0 +1,159 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"modelgate/internal/domain"
+	"modelgate/internal/replay"
+	"modelgate/internal/storage/postgres"
+)
+
+// ReplayRequest is the optional body of POST /admin/requests/{id}/replay
+type ReplayRequest struct {
+	Model string `json:"model,omitempty"` // Send to this model instead of the original
+}
+
+// ReplaySide is one of the two responses in a replay
+type ReplaySide struct {
+	Model        string  `json:"model"`
+	Response     string  `json:"response"`
+	Success      bool    `json:"success"`
+	Error        string  `json:"error,omitempty"`
+	InputTokens  int64   `json:"input_tokens"`
+	OutputTokens int64   `json:"output_tokens"`
+	CostUSD      float64 `json:"cost_usd"`
+	LatencyMs    int64   `json:"latency_ms"`
+}
+
+// ReplayDiffRow is one row of the side-by-side diff
+type ReplayDiffRow struct {
+	Kind  string  `json:"kind"`
+	Left  *string `json:"left"`
+	Right *string `json:"right"`
+}
+
+// ReplayResponse is the result of a replay
+type ReplayResponse struct {
+	RecordID  string          `json:"record_id"`
+	RequestID string          `json:"request_id"`
+	Original  ReplaySide      `json:"original"`
+	Replay    ReplaySide      `json:"replay"`
+	Diff      []ReplayDiffRow `json:"diff"`
+	Identical bool            `json:"identical"`
+}
+
+// SetReplay enables request replay in the GraphQL API and the admin endpoint
+func (s *Server) SetReplay(svc *replay.Service) {
+	s.replay = svc
+	if s.graphqlResolver != nil {
+		s.graphqlResolver.SetReplay(svc)
+	}
+}
+
+// adminSession returns the dashboard user behind the request's session token
+// if that user is an active admin
+func (s *Server) adminSession(r *http.Request) *postgres.TenantUser {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" || s.pgStore == nil {
+		return nil
+	}
+	session, user, err := s.pgStore.GetSessionByToken(r.Context(), token)
+	if err != nil || session == nil || user == nil || !user.IsActive || user.Role != "admin" {
+		return nil
+	}
+	return user
+}
+
+// handleReplayRequest re-sends a logged request and returns a diff against
+// the original response. Only admin dashboard sessions may replay requests.
+func (s *Server) handleReplayRequest(w http.ResponseWriter, r *http.Request) {
+	user := s.adminSession(r)
+	if user == nil {
+		s.writeError(w, http.StatusForbidden, "access_denied", "Replaying requests requires an admin session")
+		return
+	}
+	if s.replay == nil {
+		s.writeError(w, http.StatusNotFound, "not_found", "Request replay is not configured")
+		return
+	}
+
+	var body ReplayRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			s.writeError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON body")
+			return
+		}
+	}
+
+	recordID := r.PathValue("id")
+	result, err := s.replay.Replay(r.Context(), recordID, body.Model)
+	s.auditReplay(r, user, recordID, body.Model, result, err)
+	switch {
+	case errors.Is(err, replay.ErrNotFound):
+		s.writeError(w, http.StatusNotFound, "not_found", err.Error())
+		return
+	case errors.Is(err, replay.ErrNotCaptured):
+		s.writeError(w, http.StatusConflict, "not_replayable", err.Error())
+		return
+	case err != nil:
+		slog.Error("Failed to replay request", "record_id", recordID, "error", err)
+		s.writeError(w, http.StatusInternalServerError, "server_error", "Failed to replay request")
+		return
+	}
+
+	side := func(s replay.Side) ReplaySide {
+		return ReplaySide{
+			Model:        s.Model,
+			Response:     s.Response,
+			Success:      s.Success,
+			Error:        s.Error,
+			InputTokens:  s.InputTokens,
+			OutputTokens: s.OutputTokens,
+			CostUSD:      s.CostUSD,
+			LatencyMs:    s.LatencyMs,
+		}
+	}
+	resp := ReplayResponse{
+		RecordID:  result.RecordID,
+		RequestID: result.RequestID,
+		Original:  side(result.Original),
+		Replay:    side(result.Replay),
+		Diff:      make([]ReplayDiffRow, len(result.Diff)),
+		Identical: result.Identical,
+	}
+	for i, row := range result.Diff {
+		resp.Diff[i] = ReplayDiffRow{Kind: row.Kind, Left: row.Left, Right: row.Right}
+	}
+	s.writeJSON(w, http.StatusOK, resp)
+}
+
+// auditReplay records a replay in the audit log
+func (s *Server) auditReplay(r *http.Request, user *postgres.TenantUser, recordID, model string, result *replay.Result, err error) {
+	entry := &domain.AuditLog{
+		Action:       domain.AuditActionReplay,
+		ResourceType: domain.AuditResourceRequestLog,
+		ResourceID:   recordID,
+		ActorID:      user.ID,
+		ActorEmail:   user.Email,
+		ActorType:    "admin",
+		IPAddress:    clientIP(r, s.config.Load().Server.TrustedProxies).String(),
+		UserAgent:    r.UserAgent(),
+		Details:      map[string]any{"model_override": model},
+		Status:       "success",
+	}
+	if result != nil {
+		entry.Details["replay_request_id"] = result.RequestID
+	}
+	if err != nil {
+		entry.Status = "failure"
+		entry.ErrorMessage = err.Error()
+	}
+	if err := s.pgStore.TenantStore().CreateAuditLog(context.WithoutCancel(r.Context()), entry); err != nil {
+		slog.Error("Failed to audit request replay", "error", err)
+	}
+}
@@ -0,0 +1,49 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"modelgate/internal/domain"
+)
+
+// parseResponseFormat decodes a chat completion's response_format. It returns
+// nil when the field is absent.
+func parseResponseFormat(raw interface{}) (*domain.ResponseFormat, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid response_format: %w", err)
+	}
+	var f domain.ResponseFormat
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("invalid response_format: %w", err)
+	}
+
+	switch f.Type {
+	case domain.ResponseFormatText, domain.ResponseFormatJSONObject:
+		f.JSONSchema = nil
+		f.Grammar = ""
+	case domain.ResponseFormatJSONSchema:
+		if f.JSONSchema == nil {
+			return nil, fmt.Errorf("response_format.json_schema is required for type json_schema")
+		}
+		if f.JSONSchema.Name == "" {
+			return nil, fmt.Errorf("response_format.json_schema.name is required")
+		}
+		if f.JSONSchema.Schema == nil {
+			return nil, fmt.Errorf("response_format.json_schema.schema is required")
+		}
+		f.Grammar = ""
+	case domain.ResponseFormatGrammar:
+		if f.Grammar == "" {
+			return nil, fmt.Errorf("response_format.grammar is required for type grammar")
+		}
+		f.JSONSchema = nil
+	default:
+		return nil, fmt.Errorf("response_format.type must be text, json_object, json_schema or grammar")
+	}
+	return &f, nil
+}
@@ -1,6 +1,8 @@
 // Package http provides HTTP types for the responses API endpoint.
 package http
 
+import "modelgate/internal/domain"
+
 // ResponsesRequest is the HTTP request for POST /v1/responses
 type ResponsesRequest struct {
 	Model          string               `json:"model"`
@@ -22,12 +24,13 @@ type ResponsesSchemaInput struct {
 
 // ResponsesResponse is the HTTP response for /v1/responses
 type ResponsesResponse struct {
-	ID       string                 `json:"id"`
-	Object   string                 `json:"object"`
-	Created  int64                  `json:"created"`
-	Model    string                 `json:"model"`
-	Response map[string]interface{} `json:"response"`
-	Usage    ResponsesUsageOutput   `json:"usage"`
+	ID       string                   `json:"id"`
+	Object   string                   `json:"object"`
+	Created  int64                    `json:"created"`
+	Model    string                   `json:"model"`
+	Response map[string]interface{}   `json:"response"`
+	Usage    ResponsesUsageOutput     `json:"usage"`
+	Metadata *domain.ResponseMetadata `json:"metadata,omitempty"` // How the response was generated, including repair attempts
 }
 
 // ResponsesUsageOutput represents token usage in the response
@@ -4,32 +4,74 @@ package http
 import (
 	"context"
 	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"math"
 	"net/http"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"modelgate/internal/analytics"
+	"modelgate/internal/assistants"
+	"modelgate/internal/attestation"
+	"modelgate/internal/auditexport"
+	"modelgate/internal/batch"
+	"modelgate/internal/cache/semantic"
 	"modelgate/internal/config"
+	"modelgate/internal/customdomains"
+	"modelgate/internal/custommodels"
+	"modelgate/internal/dataaudit"
+	"modelgate/internal/deprecation"
+	"modelgate/internal/digest"
+	"modelgate/internal/documents"
 	"modelgate/internal/domain"
+	"modelgate/internal/embedders"
+	"modelgate/internal/experiments"
+	"modelgate/internal/featureflags"
+	"modelgate/internal/feedback"
+	"modelgate/internal/files"
+	"modelgate/internal/finetune"
 	"modelgate/internal/gateway"
 	"modelgate/internal/graphql/generated"
 	"modelgate/internal/graphql/resolver"
+	"modelgate/internal/healthhistory"
+	"modelgate/internal/idempotency"
+	"modelgate/internal/injection"
+	"modelgate/internal/keybudget"
+	"modelgate/internal/killswitch"
 	"modelgate/internal/mcp"
+	"modelgate/internal/ollama"
 	"modelgate/internal/policy"
+	"modelgate/internal/pricing"
+	"modelgate/internal/projects"
+	"modelgate/internal/quota"
+	"modelgate/internal/readiness"
+	"modelgate/internal/registration"
+	"modelgate/internal/replay"
 	"modelgate/internal/responses"
 	"modelgate/internal/storage/postgres"
 	"modelgate/internal/telemetry"
+	"modelgate/internal/threads"
+	"modelgate/internal/toolapproval"
+	"modelgate/internal/twofactor"
+	"modelgate/internal/usageexport"
 
 	"github.com/99designs/gqlgen/graphql/handler"
 	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
 	"github.com/99designs/gqlgen/graphql/handler/transport"
 	"github.com/99designs/gqlgen/graphql/playground"
 	"github.com/google/uuid"
+	"github.com/vektah/gqlparser/v2/ast"
 )
 
 // MCPServerInterface defines the interface for MCP server
@@ -39,7 +81,7 @@ type MCPServerInterface interface {
 
 // Server is the HTTP API server (serves both OpenAI API and GraphQL)
 type Server struct {
-	config               *config.Config
+	config               atomic.Pointer[config.Config]
 	gateway              *gateway.Service
 	dispatcher           *gateway.Dispatcher
 	pgStore              *postgres.Store
@@ -50,8 +92,30 @@ type Server struct {
 	mcpServer            MCPServerInterface
 	mcpGateway           *mcp.Gateway
 	responsesService     *responses.Service
+	fileService          *files.Service
+	batchService         *batch.Service
+	fineTuneService      *finetune.Service
+	threadService        *threads.Service
+	assistants           *assistants.Service
+	idempotency          *idempotency.Service
+	featureFlags         *featureflags.Service
+	killSwitch           *killswitch.Service
+	keyBudgets           *keybudget.Service
+	auditExport          *auditexport.Service
+	replay               *replay.Service
+	feedback             *feedback.Service
+	projects             *projects.Service
+	readiness            *readiness.Checker
 	graphqlHandler       *handler.Server
+	graphqlLimiter       *policy.RateLimiter // Per-session GraphQL rate limits
 	graphqlResolver      *resolver.Resolver
+	drain                drainState
+	asyncRequests        asyncRequests
+	dataAudit            *dataaudit.Service
+	attestation          *attestation.Service
+	deprecations         *deprecation.Service
+	domains              *customdomains.Service
+	documents            *documents.Service
 }
 
 // NewServer creates a new unified HTTP server (OpenAI API + GraphQL)
@@ -64,7 +128,6 @@ func NewServer(
 	responsesService *responses.Service,
 ) *Server {
 	s := &Server{
-		config:               cfg,
 		gateway:              gw,
 		dispatcher:           dispatcher,
 		pgStore:              pgStore,
@@ -74,6 +137,7 @@ func NewServer(
 		toolDiscoveryService: policy.NewToolDiscoveryService(),
 		responsesService:     responsesService,
 	}
+	s.config.Store(cfg)
 
 	// Initialize GraphQL handler
 	s.initGraphQL()
@@ -84,12 +148,14 @@ func NewServer(
 // initGraphQL initializes the GraphQL handler
 func (s *Server) initGraphQL() {
 	// Create resolver with all dependencies (database-only auth)
-	s.graphqlResolver = resolver.NewResolver(s.config, s.gateway, s.pgStore)
+	s.graphqlResolver = resolver.NewResolver(s.config.Load(), s.gateway, s.pgStore)
 
 	// Create GraphQL handler
-	srv := handler.NewDefaultServer(generated.NewExecutableSchema(generated.Config{
-		Resolvers: s.graphqlResolver,
+	srv := handler.New(generated.NewExecutableSchema(generated.Config{
+		Resolvers:  s.graphqlResolver,
+		Directives: resolver.Directives(),
 	}))
+	srv.AroundRootFields(resolver.RequireDeclaredAccess)
 
 	// Add transports
 	srv.AddTransport(transport.Options{})
@@ -97,12 +163,33 @@ func (s *Server) initGraphQL() {
 	srv.AddTransport(transport.POST{})
 	srv.AddTransport(transport.MultipartForm{})
 
-	// Add extensions
-	srv.Use(extension.Introspection{})
+	srv.SetQueryCache(lru.New[*ast.QueryDocument](1000))
+
+	// Add extensions. The limits go first so persisted queries are resolved
+	// before automatic persisted queries look them up.
+	cfg := s.config.Load().GraphQL
+	limits := &graphQLLimits{config: func() config.GraphQLConfig { return s.config.Load().GraphQL }}
+	if cfg.PersistedQueriesFile != "" {
+		queries, err := loadPersistedQueries(cfg.PersistedQueriesFile)
+		if err != nil {
+			slog.Error("failed to load GraphQL persisted queries", "error", err)
+		}
+		limits.persisted = queries
+	}
+	srv.Use(limits)
+	if cfg.PersistedQueriesCache > 0 {
+		srv.Use(extension.AutomaticPersistedQuery{Cache: lru.New[string](cfg.PersistedQueriesCache)})
+	}
+	s.graphqlLimiter = policy.NewRateLimiter()
 
 	s.graphqlHandler = srv
 }
 
+// SetReadiness sets the dependency checker behind /ready
+func (s *Server) SetReadiness(checker *readiness.Checker) {
+	s.readiness = checker
+}
+
 // SetMCPGateway sets the MCP gateway for GraphQL resolver
 func (s *Server) SetMCPGateway(gateway *mcp.Gateway) {
 	s.mcpGateway = gateway
@@ -111,19 +198,245 @@ func (s *Server) SetMCPGateway(gateway *mcp.Gateway) {
 	}
 }
 
+// SetConfig swaps in a reloaded configuration (auth token, model mappings).
+// The listener's port and timeouts are fixed once the server is started.
+func (s *Server) SetConfig(cfg *config.Config) {
+	s.config.Store(cfg)
+}
+
+// SetConfigHolder enables the reloadConfig GraphQL mutation
+func (s *Server) SetConfigHolder(h *config.Holder) {
+	if s.graphqlResolver != nil {
+		s.graphqlResolver.SetConfigHolder(h)
+	}
+}
+
+// SetKeyBudgets enables API key budgets on requests and in the GraphQL API
+func (s *Server) SetKeyBudgets(svc *keybudget.Service) {
+	s.keyBudgets = svc
+	if s.graphqlResolver != nil {
+		s.graphqlResolver.SetKeyBudgets(svc)
+	}
+}
+
+// SetFeatureFlags sets the feature flag service used by handlers and the GraphQL resolver
+func (s *Server) SetFeatureFlags(svc *featureflags.Service) {
+	s.featureFlags = svc
+	if s.graphqlResolver != nil {
+		s.graphqlResolver.SetFeatureFlags(svc)
+	}
+}
+
+// SetPricing enables model price management in the GraphQL API
+func (s *Server) SetPricing(svc *pricing.Service) {
+	if s.graphqlResolver != nil {
+		s.graphqlResolver.SetPricing(svc)
+	}
+}
+
+// SetUsageExport enables manual usage exports in the GraphQL API
+func (s *Server) SetUsageExport(svc *usageexport.Service) {
+	if s.graphqlResolver != nil {
+		s.graphqlResolver.SetUsageExport(svc)
+	}
+}
+
+// SetAuditExport enables audit log exports in the GraphQL API and their download endpoint
+func (s *Server) SetAuditExport(svc *auditexport.Service) {
+	s.auditExport = svc
+	if s.graphqlResolver != nil {
+		s.graphqlResolver.SetAuditExport(svc)
+	}
+}
+
+// SetOllama enables Ollama model management in the GraphQL API
+func (s *Server) SetOllama(svc *ollama.Service) {
+	if s.graphqlResolver != nil {
+		s.graphqlResolver.SetOllama(svc)
+	}
+}
+
+// SetCustomModels enables custom model registration in the GraphQL API
+func (s *Server) SetCustomModels(svc *custommodels.Service) {
+	if s.graphqlResolver != nil {
+		s.graphqlResolver.SetCustomModels(svc)
+	}
+}
+
+// SetSemanticCache enables semantic cache browsing and invalidation in the GraphQL API
+func (s *Server) SetSemanticCache(svc *semantic.TenantAwareService) {
+	if s.graphqlResolver != nil {
+		s.graphqlResolver.SetSemanticCache(svc)
+	}
+}
+
+// SetDigests enables usage digest subscriptions in the GraphQL API
+func (s *Server) SetDigests(svc *digest.Service) {
+	if s.graphqlResolver != nil {
+		s.graphqlResolver.SetDigests(svc)
+	}
+}
+
+// SetForecaster enables spend forecasts in the GraphQL API
+func (s *Server) SetForecaster(f *analytics.Forecaster) {
+	if s.graphqlResolver != nil {
+		s.graphqlResolver.SetForecaster(f)
+	}
+}
+
+// SetRegistration enables self-service registration requests in the GraphQL API
+func (s *Server) SetRegistration(svc *registration.Service) {
+	if s.graphqlResolver != nil {
+		s.graphqlResolver.SetRegistration(svc)
+	}
+}
+
+// SetQuota enables billing period quotas in the GraphQL API
+func (s *Server) SetQuota(svc *quota.Service) {
+	if s.graphqlResolver != nil {
+		s.graphqlResolver.SetQuota(svc)
+	}
+}
+
+// SetToolApprovals enables reviewing MCP tool approval requests in the GraphQL API
+func (s *Server) SetToolApprovals(svc *toolapproval.Service) {
+	if s.graphqlResolver != nil {
+		s.graphqlResolver.SetToolApprovals(svc)
+	}
+}
+
+// SetProjects enables project budgets on API requests and projects in the GraphQL API
+func (s *Server) SetProjects(svc *projects.Service) {
+	s.projects = svc
+	if s.graphqlResolver != nil {
+		s.graphqlResolver.SetProjects(svc)
+	}
+}
+
+// SetEmbedders enables per-feature embedder settings in the GraphQL API
+func (s *Server) SetEmbedders(svc *embedders.Service) {
+	if s.graphqlResolver != nil {
+		s.graphqlResolver.SetEmbedders(svc)
+	}
+}
+
+// SetTwoFactor enables two-factor authentication of dashboard logins
+func (s *Server) SetTwoFactor(svc *twofactor.Service) {
+	if s.graphqlResolver != nil {
+		s.graphqlResolver.SetTwoFactor(svc)
+	}
+}
+
+// SetHealthHistory enables the provider health timeline and SLA reports in
+// the GraphQL API
+func (s *Server) SetHealthHistory(svc *healthhistory.Service) {
+	if s.graphqlResolver != nil {
+		s.graphqlResolver.SetHealthHistory(svc)
+	}
+}
+
+// SetExperiments enables managing experiments in the GraphQL API
+func (s *Server) SetExperiments(svc *experiments.Service) {
+	if s.graphqlResolver != nil {
+		s.graphqlResolver.SetExperiments(svc)
+	}
+}
+
+// SetInjection enables managing the injection corpus in the GraphQL API
+func (s *Server) SetInjection(svc *injection.Service) {
+	if s.graphqlResolver != nil {
+		s.graphqlResolver.SetInjection(svc)
+	}
+}
+
+// featureEnabled evaluates a feature flag for the caller's role
+func (s *Server) featureEnabled(ctx context.Context, auth *AuthContext, key string) bool {
+	if auth != nil && auth.APIKey != nil && auth.APIKey.RoleID != "" {
+		return s.featureFlags.IsEnabled(ctx, key, auth.APIKey.RoleID)
+	}
+	return s.featureFlags.IsEnabled(ctx, key)
+}
+
 // setupRoutes configures all HTTP routes (OpenAI API + GraphQL)
 func (s *Server) setupRoutes() {
 	// =========================================================================
 	// OpenAI-compatible API endpoints
 	// =========================================================================
-	s.mux.HandleFunc("POST /v1/chat/completions", s.withAuthContext(s.handleChatCompletions))
-	s.mux.HandleFunc("POST /v1/embeddings", s.withAuth(s.handleEmbeddings))
+	s.mux.HandleFunc("POST /v1/chat/completions", s.withAuthContext(s.withIdempotency(s.withAttestation(s.handleChatCompletions))))
+	s.mux.HandleFunc("GET /v1/queue/{id}", s.withAuthContext(s.handleGetQueueStatus))
+	s.mux.HandleFunc("POST /v1/attestations/verify", s.withAuthContext(s.handleVerifyAttestation))
+	s.mux.HandleFunc("GET /v1/attestations/keys", s.withAuthContext(s.handleListAttestationKeys))
+	s.mux.HandleFunc("POST /v1/embeddings", s.withAuthContext(s.handleEmbeddings))
 	s.mux.HandleFunc("GET /v1/models", s.withAuthContext(s.handleListModelsFiltered))
-	s.mux.HandleFunc("GET /v1/models/{model}", s.withAuthContext(s.handleGetModelFiltered))
+	s.mux.HandleFunc("GET /v1/models/{model...}", s.withAuthContext(s.handleGetModelFiltered))
+	s.mux.HandleFunc("GET /v1/tools", s.withAuthContext(s.handleListTools))
+	s.mux.HandleFunc("POST /v1/feedback", s.withAuthContext(s.handleSubmitFeedback))
 
 	// Responses API endpoint (structured outputs)
 	if s.responsesService != nil {
-		s.mux.HandleFunc("POST /v1/responses", s.withAuthContext(s.handleResponses))
+		s.mux.HandleFunc("POST /v1/responses", s.withAuthContext(s.withIdempotency(s.handleResponses)))
+	}
+
+	// Resumable uploads for large files
+	if s.fileService != nil {
+		s.mux.HandleFunc("POST /v1/uploads", s.withAuthContext(s.handleCreateUpload))
+		s.mux.HandleFunc("GET /v1/uploads/{upload_id}", s.withAuthContext(s.handleGetUpload))
+		s.mux.HandleFunc("PUT /v1/uploads/{upload_id}/parts/{part_number}", s.withAuthContext(s.handleUploadPart))
+		s.mux.HandleFunc("POST /v1/uploads/{upload_id}/complete", s.withAuthContext(s.handleCompleteUpload))
+		s.mux.HandleFunc("POST /v1/uploads/{upload_id}/cancel", s.withAuthContext(s.handleCancelUpload))
+		s.mux.HandleFunc("POST /v1/files", s.withAuthContext(s.handleUploadFile))
+		s.mux.HandleFunc("GET /v1/files", s.withAuthContext(s.handleListFiles))
+		s.mux.HandleFunc("GET /v1/files/{file_id}", s.withAuthContext(s.handleGetFile))
+		s.mux.HandleFunc("DELETE /v1/files/{file_id}", s.withAuthContext(s.handleDeleteFile))
+		s.mux.HandleFunc("GET /v1/files/{file_id}/content", s.withAuthContext(s.handleGetFileContent))
+	}
+
+	// Documents ingested for retrieval
+	if s.documents != nil {
+		s.mux.HandleFunc("POST /v1/documents", s.withAuthContext(s.handleCreateDocument))
+		s.mux.HandleFunc("GET /v1/documents", s.withAuthContext(s.handleListDocuments))
+		s.mux.HandleFunc("GET /v1/documents/{document_id}", s.withAuthContext(s.handleGetDocument))
+		s.mux.HandleFunc("DELETE /v1/documents/{document_id}", s.withAuthContext(s.handleDeleteDocument))
+	}
+
+	// Provider-native batches (input files come from uploads)
+	if s.batchService != nil && s.fileService != nil {
+		s.mux.HandleFunc("POST /v1/batches", s.withAuthContext(s.handleCreateBatch))
+		s.mux.HandleFunc("GET /v1/batches", s.withAuthContext(s.handleListBatches))
+		s.mux.HandleFunc("GET /v1/batches/{batch_id}", s.withAuthContext(s.handleGetBatch))
+		s.mux.HandleFunc("POST /v1/batches/{batch_id}/cancel", s.withAuthContext(s.handleCancelBatch))
+	}
+
+	// Fine-tuning jobs run at providers (training files come from uploads)
+	if s.fineTuneService != nil {
+		s.mux.HandleFunc("POST /v1/fine_tuning/jobs", s.withAuthContext(s.handleCreateFineTuneJob))
+		s.mux.HandleFunc("GET /v1/fine_tuning/jobs", s.withAuthContext(s.handleListFineTuneJobs))
+		s.mux.HandleFunc("GET /v1/fine_tuning/jobs/{job_id}", s.withAuthContext(s.handleGetFineTuneJob))
+		s.mux.HandleFunc("POST /v1/fine_tuning/jobs/{job_id}/cancel", s.withAuthContext(s.handleCancelFineTuneJob))
+	}
+
+	// Stored conversation threads
+	if s.threadService != nil {
+		s.mux.HandleFunc("POST /v1/threads", s.withAuthContext(s.handleCreateThread))
+		s.mux.HandleFunc("GET /v1/threads/{thread_id}", s.withAuthContext(s.handleGetThread))
+		s.mux.HandleFunc("DELETE /v1/threads/{thread_id}", s.withAuthContext(s.handleDeleteThread))
+		s.mux.HandleFunc("POST /v1/threads/{thread_id}/messages", s.withAuthContext(s.handleAppendThreadMessages))
+		s.mux.HandleFunc("GET /v1/threads/{thread_id}/messages", s.withAuthContext(s.handleListThreadMessages))
+
+		// Assistants API subset: assistants and runs on stored threads
+		if s.assistants != nil {
+			s.mux.HandleFunc("POST /v1/assistants", s.withAuthContext(s.handleCreateAssistant))
+			s.mux.HandleFunc("GET /v1/assistants", s.withAuthContext(s.handleListAssistants))
+			s.mux.HandleFunc("GET /v1/assistants/{assistant_id}", s.withAuthContext(s.handleGetAssistant))
+			s.mux.HandleFunc("POST /v1/assistants/{assistant_id}", s.withAuthContext(s.handleModifyAssistant))
+			s.mux.HandleFunc("DELETE /v1/assistants/{assistant_id}", s.withAuthContext(s.handleDeleteAssistant))
+			s.mux.HandleFunc("POST /v1/threads/runs", s.withAuthContext(s.handleCreateThreadAndRun))
+			s.mux.HandleFunc("POST /v1/threads/{thread_id}/runs", s.withAuthContext(s.handleCreateRun))
+			s.mux.HandleFunc("GET /v1/threads/{thread_id}/runs", s.withAuthContext(s.handleListRuns))
+			s.mux.HandleFunc("GET /v1/threads/{thread_id}/runs/{run_id}", s.withAuthContext(s.handleGetRun))
+			s.mux.HandleFunc("POST /v1/threads/{thread_id}/runs/{run_id}/submit_tool_outputs", s.withAuthContext(s.handleSubmitToolOutputs))
+			s.mux.HandleFunc("POST /v1/threads/{thread_id}/runs/{run_id}/cancel", s.withAuthContext(s.handleCancelRun))
+		}
 	}
 
 	// MCP Gateway endpoint
@@ -141,9 +454,19 @@ func (s *Server) setupRoutes() {
 	// GraphQL API endpoints (for Web UI)
 	// =========================================================================
 	if s.graphqlHandler != nil {
-		s.mux.Handle("/graphql", s.withGraphQLAuth(s.graphqlHandler))
-		s.mux.Handle("/playground", playground.Handler("ModelGate GraphQL", "/graphql"))
-	}
+		s.mux.Handle("/graphql", s.withGraphQLAuth(s.withGraphQLRateLimit(s.graphqlHandler)))
+		s.mux.Handle("/playground", s.playgroundHandler(playground.Handler("ModelGate GraphQL", "/graphql")))
+		s.mux.HandleFunc("GET /exports/audit/{job_id}", s.handleDownloadAuditExport)
+		s.mux.HandleFunc("POST /admin/requests/{id}/replay", s.handleReplayRequest)
+		s.mux.HandleFunc("GET /admin/feedback/export", s.handleExportFeedback)
+		s.mux.HandleFunc("POST /admin/provider-keys/{id}/validate", s.handleValidateProviderKey)
+		s.mux.HandleFunc("GET /admin/kill-switches", s.handleListKillSwitches)
+		s.mux.HandleFunc("POST /admin/kill-switches", s.handleDisableTraffic)
+		s.mux.HandleFunc("DELETE /admin/kill-switches/{provider}", s.handleEnableTraffic)
+	}
+	s.mux.HandleFunc("GET /admin/drain", s.handleDrainStatus)
+	s.mux.HandleFunc("POST /admin/drain", s.handleStartDrain)
+	s.mux.HandleFunc("DELETE /admin/drain", s.handleCancelDrain)
 
 	// =========================================================================
 	// Infrastructure endpoints
@@ -232,6 +555,9 @@ func (s *Server) handleMCP(w http.ResponseWriter, r *http.Request) {
 // SetMCPServer sets the MCP server for handling /mcp requests
 func (s *Server) SetMCPServer(mcpServer MCPServerInterface) {
 	s.mcpServer = mcpServer
+	if server, ok := mcpServer.(*mcp.MCPServer); ok {
+		server.SetNetworkCheck(s.mcpNetworkCheck)
+	}
 	// Re-setup routes to include MCP
 	s.mux = http.NewServeMux()
 	s.setupRoutes()
@@ -239,7 +565,7 @@ func (s *Server) SetMCPServer(mcpServer MCPServerInterface) {
 
 // Handler returns the HTTP handler
 func (s *Server) Handler() http.Handler {
-	return s.corsMiddleware(s.mux)
+	return s.hostMiddleware(s.corsMiddleware(s.drainMiddleware(s.dataPlaneAuditMiddleware(s.mux))))
 }
 
 // corsMiddleware adds CORS headers
@@ -263,6 +589,11 @@ func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 type AuthContext struct {
 	Tenant *domain.Tenant
 	APIKey *domain.APIKey
+
+	// Policies of the API key's roles, loaded once per request by authRolePolicies
+	policiesLoaded bool
+	policies       []*domain.RolePolicy
+	policyErrors   []string
 }
 
 // withAuth wraps a handler with authentication
@@ -314,7 +645,7 @@ func (s *Server) withAuthContext(handler func(http.ResponseWriter, *http.Request
 				tenant, apiKey, err := s.store.TenantRepository().GetByAPIKey(r.Context(), keyHash)
 				if err != nil {
 					// Check if it's the admin token
-					if s.config.Server.AuthToken != "" && tokenStr == s.config.Server.AuthToken {
+					if authToken := s.config.Load().Server.AuthToken; authToken != "" && tokenStr == authToken {
 						// Admin access - create a synthetic tenant
 						auth.Tenant = &domain.Tenant{
 							ID:     "default",
@@ -329,18 +660,53 @@ func (s *Server) withAuthContext(handler func(http.ResponseWriter, *http.Request
 				} else {
 					auth.Tenant = tenant
 					auth.APIKey = apiKey
+					ip := clientIP(r, s.config.Load().Server.TrustedProxies)
+					if reason := s.checkNetworkAccess(r.Context(), r, auth, ip); reason != "" {
+						s.denyNetworkAccess(w, r, apiKey, ip, reason)
+						return
+					}
 				}
 			}
-		} else if s.config.Server.AuthToken != "" {
+		} else if s.config.Load().Server.AuthToken != "" {
 			// Auth is required but no token provided
 			s.writeError(w, http.StatusUnauthorized, "unauthorized", "API key or session token required")
 			return
 		}
 
+		if !hostServesTenant(r, auth.Tenant) {
+			s.writeError(w, http.StatusForbidden, "access_denied", "Credentials belong to a tenant not served on this domain")
+			return
+		}
+
+		if auth.Tenant != nil && auth.Tenant.Metadata["slug"] != "" {
+			r = r.WithContext(gateway.WithTenant(r.Context(), auth.Tenant.Metadata["slug"]))
+		}
+		if auth.APIKey != nil && strings.HasPrefix(r.URL.Path, "/v1/") {
+			s.setRateLimitStatusHeaders(w, r, auth)
+		}
+		requestAuditFrom(r.Context()).setAPIKey(auth.APIKey)
 		handler(w, r, auth)
 	}
 }
 
+// dashboardPermissions returns a dashboard user's GraphQL permissions: those
+// of their dashboard role, or the built-in ones of their user role if they
+// have none. A role that fails to load grants nothing.
+func (s *Server) dashboardPermissions(ctx context.Context, user *postgres.TenantUser) []string {
+	if user.DashboardRoleID == "" {
+		return domain.DashboardPermissions(user.Role)
+	}
+	role, err := s.pgStore.TenantStore().GetRole(ctx, user.DashboardRoleID)
+	if err != nil {
+		slog.Error("Failed to load dashboard role", "user_id", user.ID, "role_id", user.DashboardRoleID, "error", err)
+		return nil
+	}
+	if role == nil {
+		return domain.DashboardPermissions(user.Role)
+	}
+	return role.Permissions
+}
+
 // withGraphQLAuth wraps GraphQL handler with authentication context
 func (s *Server) withGraphQLAuth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -357,8 +723,12 @@ func (s *Server) withGraphQLAuth(next http.Handler) http.Handler {
 		ctx = context.WithValue(ctx, resolver.ContextKeyIPAddress, ipAddress)
 		ctx = context.WithValue(ctx, resolver.ContextKeyUserAgent, r.Header.Get("User-Agent"))
 
-		// Single-tenant mode - always use "default" tenant
+		// Single-tenant mode - always use "default" tenant, unless a custom
+		// domain names another
 		tenantSlug := "default"
+		if slug := hostTenant(ctx); slug != "" {
+			tenantSlug = slug
+		}
 		ctx = context.WithValue(ctx, resolver.ContextKeyTenant, tenantSlug)
 
 		// Create default tenant object
@@ -391,6 +761,8 @@ func (s *Server) withGraphQLAuth(next http.Handler) http.Handler {
 					}
 					ctx = context.WithValue(ctx, resolver.ContextKeyUser, domainUser)
 					ctx = context.WithValue(ctx, resolver.ContextKeyUserEmail, user.Email)
+					ctx = context.WithValue(ctx, resolver.ContextKeyIsAdmin, user.Role == "admin")
+					ctx = context.WithValue(ctx, resolver.ContextKeyPermissions, s.dashboardPermissions(ctx, user))
 				}
 			}
 		}
@@ -403,6 +775,93 @@ func (s *Server) withGraphQLAuth(next http.Handler) http.Handler {
 // SECURE BY DEFAULT: Blocks all requests unless policies are successfully loaded and validated
 // Returns a ToolPolicyResult with any removed tools (for response headers)
 func (s *Server) enforcePoliciesForRequest(ctx context.Context, req *domain.ChatRequest, auth *AuthContext) (*ToolPolicyResult, error) {
+	rolePolicies, err := s.requireRolePolicies(ctx, auth)
+	if err != nil {
+		return nil, err
+	}
+	tenantStore := s.pgStore.TenantStore()
+
+	if s.attestation.Enabled() {
+		req.PolicyVersion = attestation.PolicyVersion(rolePolicies)
+	}
+
+	// Deprecated models are reported, moved to their replacement for roles
+	// that ask for it, and refused once retired
+	deprecationHeader, err := s.applyModelDeprecation(req, rolePolicies)
+	if err != nil {
+		return nil, err
+	}
+
+	// Enforce each policy (any violation blocks the request)
+	for _, rolePolicy := range rolePolicies {
+		if err := s.gateway.EnforcePolicy(ctx, req, rolePolicy); err != nil {
+			return nil, err
+		}
+	}
+
+	// Apply generation parameter defaults and caps; with several roles the
+	// tightest cap wins
+	var clamped []string
+	for _, rolePolicy := range rolePolicies {
+		for _, c := range policy.ApplyGenerationPolicy(req, &rolePolicy.GenerationPolicy) {
+			clamped = slices.DeleteFunc(clamped, func(p string) bool { return strings.HasPrefix(p, c.Name+"=") })
+			clamped = append(clamped, c.String())
+			slog.Debug("Clamped generation parameter", "request_id", req.RequestID, "role_id", rolePolicy.RoleID,
+				"param", c.Name, "from", c.From, "to", c.Value)
+		}
+	}
+
+	// SECURITY: Enforce tool policy if request contains tools
+	var toolResult *ToolPolicyResult
+	if len(req.Tools) > 0 && auth.APIKey.RoleID != "" {
+		var err error
+		toolResult, err = s.enforceToolPolicy(ctx, req, auth, tenantStore)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Checked last so requests rejected by a role policy don't use project budget lookups
+	warning, err := s.checkProjectBudget(ctx, auth.APIKey)
+	if err != nil {
+		return nil, err
+	}
+	keyCheck, err := s.checkKeyBudget(ctx, auth.APIKey)
+	if err != nil {
+		return nil, err
+	}
+	if keyCheck != nil && keyCheck.Warning != "" {
+		warning = strings.TrimPrefix(warning+"; "+keyCheck.Warning, "; ")
+	}
+	if warning != "" || (keyCheck != nil && keyCheck.Status != nil) {
+		if toolResult == nil {
+			toolResult = &ToolPolicyResult{}
+		}
+		toolResult.BudgetWarning = warning
+		if keyCheck != nil {
+			toolResult.KeyBudget = keyCheck.Status
+		}
+	}
+	if len(clamped) > 0 {
+		if toolResult == nil {
+			toolResult = &ToolPolicyResult{}
+		}
+		toolResult.ClampedParams = clamped
+	}
+	if deprecationHeader != "" {
+		if toolResult == nil {
+			toolResult = &ToolPolicyResult{}
+		}
+		toolResult.Deprecation = deprecationHeader
+	}
+
+	return toolResult, nil
+}
+
+// requireRolePolicies returns the role policies of the caller's API key.
+// SECURE BY DEFAULT: fails unless the caller has an API key with a role or
+// group whose policies loaded.
+func (s *Server) requireRolePolicies(ctx context.Context, auth *AuthContext) ([]*domain.RolePolicy, error) {
 	// SECURITY: Require authentication
 	if auth.Tenant == nil {
 		return nil, &policy.PolicyViolation{
@@ -429,30 +888,69 @@ func (s *Server) enforcePoliciesForRequest(ctx context.Context, req *domain.Chat
 		}
 	}
 
-	// Get tenant store (single-tenant mode)
-	tenantStore := s.pgStore.TenantStore()
+	rolePolicies, policyLoadErrors := s.authRolePolicies(ctx, auth)
+
+	// SECURITY: API key must have at least a role OR group assigned
+	if auth.APIKey.RoleID == "" && auth.APIKey.GroupID == "" {
+		return nil, &policy.PolicyViolation{
+			Code:    "no_role_assigned",
+			Message: "API key must be assigned to a role or group",
+			Type:    "auth",
+		}
+	}
+
+	// SECURITY: Must successfully load at least one policy
+	if len(rolePolicies) == 0 {
+		if len(policyLoadErrors) > 0 {
+			return nil, &policy.PolicyViolation{
+				Code:    "policy_load_failed",
+				Message: fmt.Sprintf("Failed to load policies: %s", strings.Join(policyLoadErrors, "; ")),
+				Type:    "system",
+			}
+		}
+		return nil, &policy.PolicyViolation{
+			Code:    "no_policy_configured",
+			Message: "No policy configured for this API key",
+			Type:    "auth",
+		}
+	}
+	return rolePolicies, nil
+}
 
-	// Collect all role policies (direct role + group roles)
+// authRolePolicies returns the policies of the caller's API key. They are
+// loaded on first use and reused for the rest of the request.
+func (s *Server) authRolePolicies(ctx context.Context, auth *AuthContext) ([]*domain.RolePolicy, []string) {
+	if !auth.policiesLoaded {
+		auth.policies, auth.policyErrors = s.loadRolePolicies(ctx, auth.APIKey)
+		auth.policiesLoaded = true
+	}
+	return auth.policies, auth.policyErrors
+}
+
+// loadRolePolicies loads the policies of an API key's role and its group's
+// roles, along with any that failed to load
+func (s *Server) loadRolePolicies(ctx context.Context, apiKey *domain.APIKey) ([]*domain.RolePolicy, []string) {
+	tenantStore := s.pgStore.TenantStore()
 	var rolePolicies []*domain.RolePolicy
-	var policyLoadErrors []string
+	var loadErrors []string
 
 	// Get direct role policy
-	if auth.APIKey.RoleID != "" {
-		role, err := tenantStore.GetRole(ctx, auth.APIKey.RoleID)
+	if apiKey.RoleID != "" {
+		role, err := tenantStore.GetRole(ctx, apiKey.RoleID)
 		if err != nil {
-			policyLoadErrors = append(policyLoadErrors, fmt.Sprintf("failed to load role %s: %v", auth.APIKey.RoleID, err))
+			loadErrors = append(loadErrors, fmt.Sprintf("failed to load role %s: %v", apiKey.RoleID, err))
 		} else if role == nil {
-			policyLoadErrors = append(policyLoadErrors, fmt.Sprintf("role %s not found", auth.APIKey.RoleID))
+			loadErrors = append(loadErrors, fmt.Sprintf("role %s not found", apiKey.RoleID))
 		} else if role.Policy != nil {
 			rolePolicies = append(rolePolicies, role.Policy)
 		}
 	}
 
 	// Get group role policies
-	if auth.APIKey.GroupID != "" {
-		groupRoles, err := tenantStore.GetGroupRoles(ctx, auth.APIKey.GroupID)
+	if apiKey.GroupID != "" {
+		groupRoles, err := tenantStore.GetGroupRoles(ctx, apiKey.GroupID)
 		if err != nil {
-			policyLoadErrors = append(policyLoadErrors, fmt.Sprintf("failed to load group roles for %s: %v", auth.APIKey.GroupID, err))
+			loadErrors = append(loadErrors, fmt.Sprintf("failed to load group roles for %s: %v", apiKey.GroupID, err))
 		} else {
 			for _, role := range groupRoles {
 				if role.Policy != nil {
@@ -461,55 +959,321 @@ func (s *Server) enforcePoliciesForRequest(ctx context.Context, req *domain.Chat
 			}
 		}
 	}
+	return rolePolicies, loadErrors
+}
 
-	// SECURITY: API key must have at least a role OR group assigned
-	if auth.APIKey.RoleID == "" && auth.APIKey.GroupID == "" {
-		return nil, &policy.PolicyViolation{
-			Code:    "no_role_assigned",
-			Message: "API key must be assigned to a role or group",
-			Type:    "auth",
+// requirePermission checks that the API key's role or one of its group's
+// roles grants perm. Requests without an API key must come from an admin
+// session or carry the server auth token. Otherwise it writes an error and
+// returns false.
+func (s *Server) requirePermission(w http.ResponseWriter, r *http.Request, auth *AuthContext, perm string) bool {
+	if auth.APIKey == nil {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if authToken := s.config.Load().Server.AuthToken; authToken != "" && token != "" &&
+			subtle.ConstantTimeCompare([]byte(token), []byte(authToken)) == 1 {
+			return true
 		}
+		if s.adminSession(r) == nil {
+			s.writeError(w, http.StatusForbidden, "access_denied", fmt.Sprintf("Permission %s requires an API key or an admin session", perm))
+			return false
+		}
+		return true
 	}
 
-	// SECURITY: Must successfully load at least one policy
-	if len(rolePolicies) == 0 {
-		if len(policyLoadErrors) > 0 {
-			return nil, &policy.PolicyViolation{
-				Code:    "policy_load_failed",
-				Message: fmt.Sprintf("Failed to load policies: %s", strings.Join(policyLoadErrors, "; ")),
-				Type:    "system",
-			}
+	tenantStore := s.pgStore.TenantStore()
+	var roles []*domain.Role
+	if auth.APIKey.RoleID != "" {
+		role, err := tenantStore.GetRole(r.Context(), auth.APIKey.RoleID)
+		if err != nil {
+			slog.Error("Failed to load role for permission check", "role_id", auth.APIKey.RoleID, "error", err)
+			s.writeError(w, http.StatusInternalServerError, "server_error", "Failed to check permissions")
+			return false
 		}
+		if role != nil {
+			roles = append(roles, role)
+		}
+	}
+	if auth.APIKey.GroupID != "" {
+		groupRoles, err := tenantStore.GetGroupRoles(r.Context(), auth.APIKey.GroupID)
+		if err != nil {
+			slog.Error("Failed to load group roles for permission check", "group_id", auth.APIKey.GroupID, "error", err)
+			s.writeError(w, http.StatusInternalServerError, "server_error", "Failed to check permissions")
+			return false
+		}
+		roles = append(roles, groupRoles...)
+	}
+	for _, role := range roles {
+		if role.HasPermission(perm) {
+			return true
+		}
+	}
+	s.writeError(w, http.StatusForbidden, "access_denied", fmt.Sprintf("API key's role does not grant %s", perm))
+	return false
+}
+
+// checkKeyBudget rejects the request if its API key is over a hard budget
+// limit, and otherwise returns the key's budget check
+func (s *Server) checkKeyBudget(ctx context.Context, key *domain.APIKey) (*keybudget.Check, error) {
+	if s.keyBudgets == nil || key.Budget == nil {
+		return nil, nil
+	}
+	check, err := s.keyBudgets.Check(ctx, key)
+	if err != nil {
+		// A budget lookup failure should not take the gateway down
+		slog.Warn("Failed to check API key budget", "api_key_id", key.ID, "error", err)
+		return nil, nil
+	}
+	if !check.Allowed {
 		return nil, &policy.PolicyViolation{
-			Code:    "no_policy_configured",
-			Message: "No policy configured for this API key",
-			Type:    "auth",
+			Code:    "budget_exceeded",
+			Message: check.Reason,
+			Type:    "budget",
+		}
+	}
+	return check, nil
+}
+
+// checkProjectBudget rejects the request if the key's project is over a
+// blocking budget, and otherwise returns any budget warning
+func (s *Server) checkProjectBudget(ctx context.Context, key *domain.APIKey) (string, error) {
+	if s.projects == nil || key.ProjectID == "" {
+		return "", nil
+	}
+	check, err := s.projects.CheckBudget(ctx, key.ProjectID)
+	if err != nil {
+		// A budget lookup failure should not take the gateway down
+		slog.Warn("Failed to check project budget", "project_id", key.ProjectID, "error", err)
+		return "", nil
+	}
+	if !check.Allowed {
+		return "", &policy.PolicyViolation{
+			Code:    "budget_exceeded",
+			Message: check.Reason,
+			Type:    "budget",
+		}
+	}
+	return check.Warning, nil
+}
+
+// ToolPolicyResult stores the results of policy enforcement reported in response headers
+type ToolPolicyResult struct {
+	RemovedTools  []string                   // Names of tools that were stripped from request
+	AllowedTools  []string                   // Names of tools the role may call
+	BudgetWarning string                     // Project or API key budget warning, if near or over a non-blocking limit
+	KeyBudget     *domain.APIKeyBudgetStatus // Spend against the API key's budget, if it has one
+	ClampedParams []string                   // Generation parameters brought within the role's bounds, as name=value
+	Deprecation   string                     // X-ModelGate-Deprecation value when the requested model is deprecated
+}
+
+// setPolicyHeaders reports what policy enforcement changed or warned about.
+// Must be called before the response body is written.
+func setPolicyHeaders(w http.ResponseWriter, result *ToolPolicyResult) {
+	if result == nil {
+		return
+	}
+	if len(result.RemovedTools) > 0 {
+		w.Header().Set("X-ModelGate-Removed-Tools", strings.Join(result.RemovedTools, ","))
+		w.Header().Set("X-ModelGate-Warning", fmt.Sprintf("%d tool(s) removed from request", len(result.RemovedTools)))
+	}
+	if len(result.ClampedParams) > 0 {
+		w.Header().Set("X-ModelGate-Params-Clamped", strings.Join(result.ClampedParams, ","))
+	}
+	if result.BudgetWarning != "" {
+		w.Header().Set("X-ModelGate-Budget-Warning", result.BudgetWarning)
+	}
+	if result.Deprecation != "" {
+		w.Header().Set(deprecation.HeaderName, result.Deprecation)
+	}
+	if kb := result.KeyBudget; kb != nil {
+		if kb.DailyRemainingUSD != nil {
+			w.Header().Set("X-ModelGate-Budget-Remaining-Daily", strconv.FormatFloat(*kb.DailyRemainingUSD, 'f', 4, 64))
 		}
+		if kb.MonthlyRemainingUSD != nil {
+			w.Header().Set("X-ModelGate-Budget-Remaining-Monthly", strconv.FormatFloat(*kb.MonthlyRemainingUSD, 'f', 4, 64))
+		}
+	}
+}
+
+// setRateLimitHeaders reports the rate limit windows (OpenAI-style) and any burst credits in use
+func setRateLimitHeaders(w http.ResponseWriter, state *domain.RateLimitState) {
+	if state == nil {
+		return
+	}
+	windows := []struct {
+		kind   string
+		window *domain.RateLimitWindow
+	}{
+		{"Requests", state.Requests},
+		{"Tokens", state.Tokens},
+	}
+	for _, wnd := range windows {
+		if wnd.window == nil {
+			continue
+		}
+		reset := time.Until(wnd.window.Reset).Round(time.Second)
+		if reset < 0 {
+			reset = 0
+		}
+		w.Header().Set("X-RateLimit-Limit-"+wnd.kind, strconv.Itoa(wnd.window.Limit))
+		w.Header().Set("X-RateLimit-Remaining-"+wnd.kind, strconv.Itoa(wnd.window.Remaining))
+		w.Header().Set("X-RateLimit-Reset-"+wnd.kind, reset.String())
+		if wnd.window.Borrowed > 0 {
+			w.Header().Set("X-ModelGate-Burst-Borrowed-"+wnd.kind, strconv.Itoa(wnd.window.Borrowed))
+		}
+		if wnd.window.Payback > 0 {
+			w.Header().Set("X-ModelGate-Burst-Payback-"+wnd.kind, strconv.Itoa(wnd.window.Payback))
+		}
+	}
+}
+
+// setRateLimitStatusHeaders reports the API key's current rate limit windows
+// on any /v1 response. Handlers that consume from the windows overwrite them
+// with the state after the request.
+func (s *Server) setRateLimitStatusHeaders(w http.ResponseWriter, r *http.Request, auth *AuthContext) {
+	if s.pgStore == nil || !s.featureEnabled(r.Context(), auth, domain.FeatureRateLimitHeaders) {
+		return
+	}
+	rolePolicies, _ := s.authRolePolicies(r.Context(), auth)
+
+	// Every policy is enforced, so report the tightest window of each kind
+	var state *domain.RateLimitState
+	for _, rolePolicy := range rolePolicies {
+		status := s.gateway.RateLimitStatus(auth.APIKey.ID, rolePolicy)
+		if status == nil {
+			continue
+		}
+		if state == nil {
+			state = &domain.RateLimitState{}
+		}
+		if status.Requests != nil && (state.Requests == nil || status.Requests.Remaining < state.Requests.Remaining) {
+			state.Requests = status.Requests
+		}
+		if status.Tokens != nil && (state.Tokens == nil || status.Tokens.Remaining < state.Tokens.Remaining) {
+			state.Tokens = status.Tokens
+		}
+	}
+	setRateLimitHeaders(w, state)
+}
+
+// setRetryAfter tells a rate limited client how long until the window that
+// denied it and its other exhausted windows reset. A window with capacity
+// left doesn't hold the client back.
+func setRetryAfter(w http.ResponseWriter, state *domain.RateLimitState) {
+	var reset time.Time
+	for _, window := range []*domain.RateLimitWindow{state.Requests, state.Tokens} {
+		if window != nil && (window == state.Exceeded || window.Remaining == 0) && window.Reset.After(reset) {
+			reset = window.Reset
+		}
+	}
+	seconds := max(int(math.Ceil(time.Until(reset).Seconds())), 1)
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+}
+
+// setContextHeaders reports how context window management changed the request.
+// Must be called before the response body is written.
+func setContextHeaders(w http.ResponseWriter, action *domain.ContextAction) {
+	if action == nil {
+		return
+	}
+	w.Header().Set("X-ModelGate-Context-Strategy", string(action.Strategy))
+	w.Header().Set("X-ModelGate-Context-Estimated-Tokens", strconv.Itoa(action.EstimatedTokens))
+	if action.DroppedMessages > 0 {
+		w.Header().Set("X-ModelGate-Context-Dropped-Messages", strconv.Itoa(action.DroppedMessages))
+	}
+	if action.SummarizedMessages > 0 {
+		w.Header().Set("X-ModelGate-Context-Summarized-Messages", strconv.Itoa(action.SummarizedMessages))
+	}
+	if action.OriginalModel != "" {
+		w.Header().Set("X-ModelGate-Context-Model", action.Model)
+	}
+}
+
+// setLimitHeaders reports the deadline and cost cap the gateway applied to the
+// request, after clamping them to the role's maxima.
+// Must be called before the response body is written.
+func setLimitHeaders(w http.ResponseWriter, limits domain.RequestLimits) {
+	if limits.Timeout > 0 {
+		w.Header().Set("X-ModelGate-Timeout-Ms", strconv.FormatInt(limits.Timeout.Milliseconds(), 10))
+	}
+	if limits.MaxCostUSD > 0 {
+		w.Header().Set("X-ModelGate-Max-Cost", strconv.FormatFloat(limits.MaxCostUSD, 'f', -1, 64))
+	}
+}
+
+// setRoutingHeaders reports which model intelligent routing selected and why.
+// Must be called before the response body is written.
+func setRoutingHeaders(w http.ResponseWriter, decision *domain.RoutingDecision) {
+	if decision == nil {
+		return
+	}
+	w.Header().Set("X-ModelGate-Routing-Strategy", string(decision.Strategy))
+	w.Header().Set("X-ModelGate-Routing-Model", decision.Model)
+	if decision.Reason != "" {
+		w.Header().Set("X-ModelGate-Routing-Reason", decision.Reason)
+	}
+	if decision.LatencyMs > 0 {
+		w.Header().Set("X-ModelGate-Routing-Latency-Ms", strconv.FormatFloat(decision.LatencyMs, 'f', 0, 64))
+		w.Header().Set("X-ModelGate-Routing-Percentile", decision.Percentile)
 	}
+	if decision.Sticky {
+		w.Header().Set("X-ModelGate-Routing-Sticky", "true")
+	}
+}
 
-	// Enforce each policy (any violation blocks the request)
-	for _, rolePolicy := range rolePolicies {
-		if err := s.gateway.EnforcePolicy(ctx, req, rolePolicy); err != nil {
-			return nil, err
-		}
+// setTransformHeaders reports what the role's output transform policy changed.
+// A stream's text is transformed as it is sent, so only its usage record
+// notes the changes. Must be called before the response body is written.
+func setTransformHeaders(w http.ResponseWriter, transform *domain.OutputTransform) {
+	if transform == nil {
+		return
 	}
+	w.Header().Set("X-ModelGate-Output-Transformed", strings.Join(transform.Applied, ","))
+}
 
-	// SECURITY: Enforce tool policy if request contains tools
-	var toolResult *ToolPolicyResult
-	if len(req.Tools) > 0 && auth.APIKey.RoleID != "" {
-		var err error
-		toolResult, err = s.enforceToolPolicy(ctx, req, auth, tenantStore)
-		if err != nil {
-			return nil, err
-		}
+// setCacheHeaders reports how the semantic cache answered the request: the
+// status, the similarity of a semantic hit and the age of the cached response.
+// Must be called before the response body is written.
+func setCacheHeaders(w http.ResponseWriter, lookup *domain.CacheLookup) {
+	if lookup == nil {
+		return
+	}
+	w.Header().Set("X-ModelGate-Cache", string(lookup.Status))
+	if lookup.Status == domain.CacheStatusSemanticHit {
+		w.Header().Set("X-ModelGate-Cache-Similarity", strconv.FormatFloat(lookup.Similarity, 'f', 4, 64))
+	}
+	if !lookup.StoredAt.IsZero() {
+		age := max(int(time.Since(lookup.StoredAt).Seconds()), 0)
+		w.Header().Set("Age", strconv.Itoa(age))
 	}
+}
 
-	return toolResult, nil
+// setETag sets a weak ETag on the response to a deterministic (temperature 0)
+// request, derived from the model's output so identical answers share a tag
+// even though each response has its own ID. Must be called before the
+// response body is written.
+func setETag(w http.ResponseWriter, req *ChatCompletionRequest, resp *domain.ChatResponse) {
+	if req.Temperature == nil || *req.Temperature != 0 {
+		return
+	}
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s", req.Model, resp.FinishReason, resp.Content)
+	for _, tc := range resp.ToolCalls {
+		args, _ := json.Marshal(tc.Function.Arguments)
+		fmt.Fprintf(h, "\x00%s\x00%s", tc.Function.Name, args)
+	}
+	w.Header().Set("ETag", fmt.Sprintf(`W/"%x"`, h.Sum(nil)[:16]))
 }
 
-// ToolPolicyResult stores the result of tool policy enforcement for response headers
-type ToolPolicyResult struct {
-	RemovedTools []string // Names of tools that were stripped from request
+// roleToolPolicies returns the policy that decides a role's pending tools
+func roleToolPolicies(role *domain.Role) domain.EnhancedToolPolicies {
+	toolPolicies := domain.DefaultEnhancedToolPolicies()
+	if role != nil && role.Policy != nil {
+		// Check if requireToolApproval is set - if so, use BLOCK as default
+		if role.Policy.ToolPolicies.RequireToolApproval {
+			toolPolicies.DefaultAction = "BLOCK"
+		}
+	}
+	return toolPolicies
 }
 
 // enforceToolPolicy discovers tools and checks if they are allowed for the role
@@ -550,20 +1314,11 @@ func (s *Server) enforceToolPolicy(ctx context.Context, req *domain.ChatRequest,
 
 	// Check permissions for discovered tools
 	if len(discoveredTools) > 0 {
-		// Get the tool policy from role
-		toolPolicies := domain.DefaultEnhancedToolPolicies()
-		if role != nil && role.Policy != nil {
-			// Check if requireToolApproval is set - if so, use BLOCK as default
-			if role.Policy.ToolPolicies.RequireToolApproval {
-				toolPolicies.DefaultAction = "BLOCK"
-			}
-		}
-
 		permResult, err := s.toolDiscoveryService.CheckToolPermissions(
 			ctx,
 			auth.APIKey.RoleID,
 			discoveredTools,
-			toolPolicies,
+			roleToolPolicies(role),
 			tenantStore,
 		)
 		if err != nil {
@@ -664,6 +1419,7 @@ func (s *Server) enforceToolPolicy(ctx context.Context, req *domain.ChatRequest,
 
 		// Log allowed tool executions
 		for _, t := range permResult.AllowedTools() {
+			result.AllowedTools = append(result.AllowedTools, t.ToolName)
 			tenantStore.LogToolExecution(ctx, &domain.ToolExecutionLog{
 				ID:         uuid.New().String(),
 				RoleToolID: t.ToolID,
@@ -690,17 +1446,23 @@ func (s *Server) writePolicyViolationError(w http.ResponseWriter, err error) {
 	// Map policy type to HTTP status code
 	statusCode := http.StatusForbidden
 	switch policyViolation.Type {
-	case "rate_limit":
+	case "rate_limit", "budget":
 		statusCode = http.StatusTooManyRequests
+	case "sunset":
+		statusCode = http.StatusGone
 	case "model":
 		statusCode = http.StatusForbidden
-	case "prompt", "tool":
+	case "prompt", "tool", "embedding":
 		statusCode = http.StatusBadRequest
 	case "auth":
 		statusCode = http.StatusUnauthorized // 401 for authentication failures
 	case "system":
 		statusCode = http.StatusServiceUnavailable // 503 for system errors
 	}
+	if policyViolation.RateLimit != nil {
+		setRateLimitHeaders(w, policyViolation.RateLimit)
+		setRetryAfter(w, policyViolation.RateLimit)
+	}
 
 	s.writeJSON(w, statusCode, ErrorResponse{
 		Error: ErrorDetail{
@@ -740,8 +1502,8 @@ func (s *Server) recordPolicyViolation(ctx context.Context, req *domain.ChatRequ
 
 	// Determine provider from model
 	provider := domain.Provider("unknown")
-	if s.config != nil {
-		if p, ok := s.config.GetProviderForModel(req.Model); ok {
+	if cfg := s.config.Load(); cfg != nil {
+		if p, ok := cfg.GetProviderForModel(req.Model); ok {
 			provider = p
 		}
 	}
@@ -779,6 +1541,7 @@ func (s *Server) recordPolicyViolation(ctx context.Context, req *domain.ChatRequ
 	record := &domain.UsageRecord{
 		ID:           uuid.New().String(),
 		APIKeyID:     req.APIKeyID,
+		ProjectID:    req.ProjectID,
 		RequestID:    requestID,
 		Model:        req.Model,
 		Provider:     provider,
@@ -815,18 +1578,60 @@ func (s *Server) handleChatCompletions(w http.ResponseWriter, r *http.Request, a
 		s.writeError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON body")
 		return
 	}
+	if _, err := parseResponseFormat(req.ResponseFormat); err != nil {
+		s.writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if _, err := parseToolChoice(req.ToolChoice); err != nil {
+		s.writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	cacheControl, err := parseCacheControl(r.Header.Get("X-ModelGate-Cache"))
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	conversationID, err := parseConversationID(r.Header.Get("X-ModelGate-Conversation-ID"))
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if conversationID == "" {
+		conversationID = req.ThreadID // A thread is a conversation
+	}
+	limits, err := parseRequestLimits(r.Header)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
 
 	// Convert to domain request
 	domainReq := s.convertChatRequest(&req)
+	domainReq.CacheControl = cacheControl
+	domainReq.ConversationID = conversationID
+	domainReq.Limits = limits
+	if ip := clientIP(r, s.config.Load().Server.TrustedProxies); ip.IsValid() {
+		domainReq.ClientIP = ip.String()
+	}
 	// Pass API key and role/group info for RBAC policy enforcement
 	if auth.APIKey != nil {
 		domainReq.APIKeyID = auth.APIKey.ID
 		domainReq.RoleID = auth.APIKey.RoleID
 		domainReq.GroupID = auth.APIKey.GroupID
+		domainReq.ProjectID = auth.APIKey.ProjectID
+	}
+
+	requestAuditFrom(r.Context()).setChatRequest(domainReq)
+	requestAttestationFrom(r.Context()).setChatRequest(domainReq)
+
+	// Prepend stored history so policies see the whole conversation
+	if !s.beginThreadTurn(w, r, &req, domainReq, auth) {
+		return
 	}
 
 	// Enforce policies before processing request
 	toolResult, err := s.enforcePoliciesForRequest(r.Context(), domainReq, auth)
+	requestAuditFrom(r.Context()).setPolicyOutcome(toolResult, err)
 	if err != nil {
 		// Record policy violation in usage logs for visibility
 		s.recordPolicyViolation(r.Context(), domainReq, auth, err, startTime)
@@ -835,9 +1640,23 @@ func (s *Server) handleChatCompletions(w http.ResponseWriter, r *http.Request, a
 	}
 
 	// Add headers for removed tools (if any)
-	if toolResult != nil && len(toolResult.RemovedTools) > 0 {
-		w.Header().Set("X-ModelGate-Removed-Tools", strings.Join(toolResult.RemovedTools, ","))
-		w.Header().Set("X-ModelGate-Warning", fmt.Sprintf("%d tool(s) removed from request", len(toolResult.RemovedTools)))
+	setPolicyHeaders(w, toolResult)
+	if s.featureEnabled(r.Context(), auth, domain.FeatureRateLimitHeaders) {
+		setRateLimitHeaders(w, domainReq.RateLimit)
+	}
+
+	// Fill in files referenced by ID
+	if s.fileService != nil {
+		if err := s.fileService.ResolveContent(r.Context(), domainReq, authAPIKeyID(auth)); err != nil {
+			s.writeUploadError(w, err)
+			return
+		}
+	}
+
+	// Replace references to stored documents with their most relevant chunks
+	if err := s.retrieveDocuments(r.Context(), domainReq, &req, auth); err != nil {
+		s.writeDocumentError(w, err)
+		return
 	}
 
 	// If dispatcher is available, use it for backpressure and queuing
@@ -856,8 +1675,8 @@ func (s *Server) handleChatCompletions(w http.ResponseWriter, r *http.Request, a
 
 // handleChatCompletionsWithDispatcher uses the dispatcher for backpressure
 func (s *Server) handleChatCompletionsWithDispatcher(w http.ResponseWriter, r *http.Request, domainReq *domain.ChatRequest, req *ChatCompletionRequest, auth *AuthContext) {
-	// Determine priority from role policy
-	priority := s.getPriorityForRequest(r.Context(), auth)
+	// Determine priority and concurrency limits from role policy
+	priority, concurrency, roleLimits := s.getConcurrencyForRequest(r.Context(), auth)
 
 	// Create dispatch request
 	dispatchReq := &gateway.DispatchRequest{
@@ -869,10 +1688,34 @@ func (s *Server) handleChatCompletionsWithDispatcher(w http.ResponseWriter, r *h
 		RoleID:     domainReq.RoleID,
 		GroupID:    domainReq.GroupID,
 		Priority:   priority,
+		Weight:     concurrency.Weight,
+
+		MaxConcurrentPerKey: int32(concurrency.MaxConcurrentPerKey),
+		RoleLimits:          roleLimits,
+		QueueWhenLimited:    concurrency.QueueWhenLimited,
+
+		IdempotencyKey: r.Header.Get("Idempotency-Key"),
+	}
+
+	if !req.Stream && preferRespondAsync(r) {
+		s.acceptAsyncRequest(w, r, dispatchReq, domainReq, req)
+		return
+	}
+	s.dispatchChatCompletion(w, r, dispatchReq, domainReq, req)
+}
+
+// dispatchChatCompletion submits a request to the dispatcher and writes its result
+func (s *Server) dispatchChatCompletion(w http.ResponseWriter, r *http.Request, dispatchReq *gateway.DispatchRequest, domainReq *domain.ChatRequest, req *ChatCompletionRequest) {
+	// Streams can report their place in the queue before the first chunk
+	events := &queueEvents{w: w}
+	if req.Stream && r.Header.Get(queueEventsHeader) == "true" {
+		dispatchReq.OnQueued = events.send
 	}
 
 	// Submit to dispatcher
 	result, err := s.dispatcher.Submit(r.Context(), dispatchReq)
+	// Once queue events have started the stream, errors are sent as an event
+	w = events.errorWriter()
 	if err != nil {
 		if err == gateway.ErrQueueFull {
 			// Backpressure: server is overloaded
@@ -887,6 +1730,11 @@ func (s *Server) handleChatCompletionsWithDispatcher(w http.ResponseWriter, r *h
 				"Request timed out waiting in queue")
 			return
 		}
+		if err == gateway.ErrKeyLimited || err == gateway.ErrRoleLimited {
+			w.Header().Set("Retry-After", "1")
+			s.writeError(w, http.StatusTooManyRequests, "concurrency_limit_exceeded", err.Error())
+			return
+		}
 		if err == gateway.ErrShuttingDown {
 			s.writeError(w, http.StatusServiceUnavailable, "shutting_down",
 				"Server is shutting down")
@@ -897,52 +1745,84 @@ func (s *Server) handleChatCompletionsWithDispatcher(w http.ResponseWriter, r *h
 	}
 
 	// Handle the result
+	setLimitHeaders(w, domainReq.Limits)
+	setContextHeaders(w, domainReq.ContextAction)
+	setRoutingHeaders(w, domainReq.RoutingDecision)
+	setCacheHeaders(w, domainReq.CacheLookup)
+	setTransformHeaders(w, domainReq.OutputTransform)
 	if req.Stream {
 		if result.Error != nil {
-			s.writeError(w, http.StatusInternalServerError, "stream_error", result.Error.Error())
+			s.writeGatewayError(w, result.Error, "stream_error")
 			return
 		}
-		s.handleStreamingResponseFromEvents(w, r, result.EventsCh, req)
+		s.handleStreamingResponseFromEvents(events.w, r, result.EventsCh, domainReq.RequestID, req)
 	} else {
 		if result.Error != nil {
-			s.writeError(w, http.StatusInternalServerError, "completion_error", result.Error.Error())
+			s.writeGatewayError(w, result.Error, "completion_error")
 			return
 		}
-		s.handleNonStreamingResponseFromResult(w, result.Response, req)
+		if result.Replayed {
+			w.Header().Set("Idempotent-Replayed", "true")
+		}
+		s.handleNonStreamingResponseFromResult(r.Context(), w, result.Response, domainReq.RequestID, req)
 	}
 }
 
-// getPriorityForRequest determines request priority from role policy
-func (s *Server) getPriorityForRequest(ctx context.Context, auth *AuthContext) int {
+// getConcurrencyForRequest determines request priority and concurrency limits
+// from the concurrency policies of every role the API key holds, directly or
+// through its group. The highest priority and weight apply, with the
+// tightest per-key limit; the per-role limits of each role are returned by
+// role ID.
+func (s *Server) getConcurrencyForRequest(ctx context.Context, auth *AuthContext) (int, domain.ConcurrencyPolicy, map[string]int32) {
 	// Default priority
 	priority := 5
 
 	if auth.APIKey == nil || s.pgStore == nil {
-		return priority
+		return priority, domain.ConcurrencyPolicy{}, nil
 	}
 
-	// Get role policy to check for priority settings (single-tenant mode)
-	tenantStore := s.pgStore.TenantStore()
-
-	// Get role policy
-	rolePolicy, err := tenantStore.GetRolePolicy(ctx, auth.APIKey.RoleID)
-	if err != nil || rolePolicy == nil {
-		return priority
+	// Roles that fail to load are refused by policy enforcement
+	rolePolicies, _ := s.authRolePolicies(ctx, auth)
+	var merged domain.ConcurrencyPolicy
+	roleLimits := make(map[string]int32, len(rolePolicies))
+	for _, rolePolicy := range rolePolicies {
+		cp := rolePolicy.ConcurrencyPolicy
+		if !cp.Enabled {
+			roleLimits[rolePolicy.RoleID] = 0
+			continue
+		}
+		merged.Enabled = true
+		merged.Priority = max(merged.Priority, cp.Priority)
+		merged.Weight = max(merged.Weight, cp.Weight)
+		if cp.MaxConcurrentPerKey > 0 && (merged.MaxConcurrentPerKey == 0 || cp.MaxConcurrentPerKey < merged.MaxConcurrentPerKey) {
+			merged.MaxConcurrentPerKey = cp.MaxConcurrentPerKey
+		}
+		merged.QueueWhenLimited = merged.QueueWhenLimited || cp.QueueWhenLimited
+		roleLimits[rolePolicy.RoleID] = int32(cp.MaxConcurrentPerRole)
 	}
 
 	// Use concurrency policy priority if configured
-	if rolePolicy.ConcurrencyPolicy.Enabled && rolePolicy.ConcurrencyPolicy.Priority > 0 {
-		priority = rolePolicy.ConcurrencyPolicy.Priority
-		if priority > 10 {
-			priority = 10
-		}
+	if merged.Priority > 0 {
+		priority = min(merged.Priority, 10)
 	}
+	if len(roleLimits) == 0 {
+		roleLimits = nil // The dispatcher limits the key's role with the defaults
+	}
+
+	return priority, merged, roleLimits
+}
 
-	return priority
+// completionID is the ID of a chat completion. It carries the request ID so
+// that POST /v1/feedback can find the response's usage record.
+func completionID(requestID string) string {
+	if requestID == "" {
+		requestID = uuid.New().String()
+	}
+	return "chatcmpl-" + requestID
 }
 
 // handleStreamingResponseFromEvents handles streaming from dispatcher result
-func (s *Server) handleStreamingResponseFromEvents(w http.ResponseWriter, r *http.Request, events <-chan domain.StreamEvent, req *ChatCompletionRequest) {
+func (s *Server) handleStreamingResponseFromEvents(w http.ResponseWriter, r *http.Request, events <-chan domain.StreamEvent, requestID string, req *ChatCompletionRequest) {
 	// Set SSE headers
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
@@ -956,9 +1836,10 @@ func (s *Server) handleStreamingResponseFromEvents(w http.ResponseWriter, r *htt
 
 	rc := http.NewResponseController(w)
 
-	id := fmt.Sprintf("chatcmpl-%s", uuid.New().String())
+	id := completionID(requestID)
 	created := time.Now().Unix()
 	chunkCount := 0
+	var toolCalls toolCallChunker
 
 	// Set initial write deadline
 	if err := rc.SetWriteDeadline(time.Now().Add(30 * time.Minute)); err != nil {
@@ -977,13 +1858,16 @@ func (s *Server) handleStreamingResponseFromEvents(w http.ResponseWriter, r *htt
 				Role: stringPtr("assistant"),
 			},
 		}},
+		Documents: req.retrieved,
 	}); err != nil {
 		slog.Error("Failed to write initial SSE chunk", "error", err)
 		return
 	}
+	defer s.trackStream()()
 
-	for event := range events {
+	for event := range sseEvents(r.Context(), w, flusher, events, sseKeepAliveInterval) {
 		chunkCount++
+		req.thread.observe(event)
 
 		if chunkCount%50 == 0 {
 			if err := rc.SetWriteDeadline(time.Now().Add(30 * time.Minute)); err != nil {
@@ -1008,8 +1892,21 @@ func (s *Server) handleStreamingResponseFromEvents(w http.ResponseWriter, r *htt
 				}},
 			})
 
-		case domain.ToolCallEvent:
-			argsJSON, _ := json.Marshal(e.ToolCall.Function.Arguments)
+		case domain.ToolCallDelta, domain.ToolCallEvent:
+			if tc, ok := toolCalls.chunk(e); ok {
+				writeErr = s.writeSSEChunk(w, flusher, ChatCompletionChunk{
+					ID:      id,
+					Object:  "chat.completion.chunk",
+					Created: created,
+					Model:   req.Model,
+					Choices: []ChunkChoice{{
+						Index: 0,
+						Delta: Delta{ToolCalls: []ToolCallChunk{tc}},
+					}},
+				})
+			}
+
+		case domain.CitationEvent:
 			writeErr = s.writeSSEChunk(w, flusher, ChatCompletionChunk{
 				ID:      id,
 				Object:  "chat.completion.chunk",
@@ -1017,16 +1914,7 @@ func (s *Server) handleStreamingResponseFromEvents(w http.ResponseWriter, r *htt
 				Model:   req.Model,
 				Choices: []ChunkChoice{{
 					Index: 0,
-					Delta: Delta{
-						ToolCalls: []ToolCall{{
-							ID:   e.ToolCall.ID,
-							Type: "function",
-							Function: &FunctionCall{
-								Name:      e.ToolCall.Function.Name,
-								Arguments: string(argsJSON),
-							},
-						}},
-					},
+					Delta: Delta{Citations: citations([]domain.Citation{e.Citation})},
 				}},
 			})
 
@@ -1038,6 +1926,8 @@ func (s *Server) handleStreamingResponseFromEvents(w http.ResponseWriter, r *htt
 				reason = "length"
 			} else if e.Reason == domain.FinishReasonError {
 				reason = "error"
+			} else if e.Reason == domain.FinishReasonPolicyViolation {
+				reason = "policy_violation"
 			}
 			writeErr = s.writeSSEChunk(w, flusher, ChatCompletionChunk{
 				ID:      id,
@@ -1051,6 +1941,10 @@ func (s *Server) handleStreamingResponseFromEvents(w http.ResponseWriter, r *htt
 				}},
 			})
 
+		case domain.ErrorEvent:
+			// The FinishEvent that follows closes the choice with reason "error"
+			writeErr = s.writeSSEChunk(w, flusher, ErrorResponse{Error: providerErrorDetail(e.Err)})
+
 		case domain.PolicyViolationEvent:
 			writeErr = s.writeSSEChunk(w, flusher, ChatCompletionChunk{
 				ID:      id,
@@ -1068,20 +1962,22 @@ func (s *Server) handleStreamingResponseFromEvents(w http.ResponseWriter, r *htt
 
 		if writeErr != nil {
 			slog.Error("Failed to write SSE chunk", "error", writeErr, "chunk", chunkCount)
-			for range events {
-				// Drain remaining events
-			}
 			return
 		}
 	}
+	if r.Context().Err() != nil {
+		slog.Info("Client disconnected, stream cancelled", "chunks", chunkCount)
+		return
+	}
 
 	// Send done marker
 	fmt.Fprintf(w, "data: [DONE]\n\n")
 	flusher.Flush()
+	s.finishThreadTurn(r.Context(), req, nil)
 }
 
 // handleNonStreamingResponseFromResult handles non-streaming from dispatcher result
-func (s *Server) handleNonStreamingResponseFromResult(w http.ResponseWriter, resp *domain.ChatResponse, req *ChatCompletionRequest) {
+func (s *Server) handleNonStreamingResponseFromResult(ctx context.Context, w http.ResponseWriter, resp *domain.ChatResponse, requestID string, req *ChatCompletionRequest) {
 	if resp == nil {
 		s.writeError(w, http.StatusInternalServerError, "no_response", "No response received")
 		return
@@ -1089,8 +1985,9 @@ func (s *Server) handleNonStreamingResponseFromResult(w http.ResponseWriter, res
 
 	// Build message
 	msg := ChatMessage{
-		Role:    "assistant",
-		Content: resp.Content,
+		Role:      "assistant",
+		Content:   resp.Content,
+		Citations: citations(resp.Citations),
 	}
 
 	// Handle tool calls
@@ -1118,7 +2015,7 @@ func (s *Server) handleNonStreamingResponseFromResult(w http.ResponseWriter, res
 
 	// Build response
 	response := ChatCompletionResponse{
-		ID:      fmt.Sprintf("chatcmpl-%s", uuid.New().String()),
+		ID:      completionID(requestID),
 		Object:  "chat.completion",
 		Created: time.Now().Unix(),
 		Model:   req.Model,
@@ -1127,6 +2024,7 @@ func (s *Server) handleNonStreamingResponseFromResult(w http.ResponseWriter, res
 			Message:      msg,
 			FinishReason: reason,
 		}},
+		Documents: req.retrieved,
 	}
 
 	// Add usage if available
@@ -1138,8 +2036,10 @@ func (s *Server) handleNonStreamingResponseFromResult(w http.ResponseWriter, res
 		}
 	}
 
+	setETag(w, req, resp)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
+	s.finishThreadTurn(ctx, req, resp)
 }
 
 // handleDispatcherStats returns dispatcher statistics
@@ -1169,12 +2069,21 @@ func (s *Server) handleDispatcherStats(w http.ResponseWriter, r *http.Request) {
 			"low_priority":    stats.LowPriorityQueueDepth,
 		},
 		"requests": map[string]interface{}{
-			"received":  stats.RequestsReceived,
-			"queued":    stats.RequestsQueued,
-			"processed": stats.RequestsProcessed,
-			"rejected":  stats.RequestsRejected,
-			"timed_out": stats.RequestsTimedOut,
+			"received":     stats.RequestsReceived,
+			"queued":       stats.RequestsQueued,
+			"processed":    stats.RequestsProcessed,
+			"rejected":     stats.RequestsRejected,
+			"timed_out":    stats.RequestsTimedOut,
+			"key_limited":  stats.RequestsKeyLimited,
+			"role_limited": stats.RequestsRoleLimited,
+			"deduplicated": stats.RequestsDeduplicated,
+			"recovered":    stats.RequestsRecovered,
 		},
+		"concurrency": map[string]interface{}{
+			"api_keys": s.dispatcher.APIKeyStats(),
+			"roles":    s.dispatcher.RoleStats(),
+		},
+		"fairness": s.dispatcher.Fairness(),
 		"timing_ms": map[string]interface{}{
 			"avg_queue_wait":  s.dispatcher.AvgQueueWaitMs(),
 			"avg_processing":  s.dispatcher.AvgProcessingMs(),
@@ -1185,6 +2094,11 @@ func (s *Server) handleDispatcherStats(w http.ResponseWriter, r *http.Request) {
 		},
 	}
 
+	// Upstream rate limits last reported by each provider key
+	if s.gateway != nil {
+		response["provider_quotas"] = s.gateway.ProviderQuotas()
+	}
+
 	// Include tenant stats if requested
 	tenantID := r.URL.Query().Get("tenant")
 	if tenantID != "" {
@@ -1218,14 +2132,26 @@ func (s *Server) handleStreamingResponse(w http.ResponseWriter, r *http.Request,
 	rc := http.NewResponseController(w)
 
 	events, err := s.gateway.ChatStream(r.Context(), domainReq)
+	setLimitHeaders(w, domainReq.Limits)
 	if err != nil {
+		// Nothing has been streamed yet, so a provider failure still gets its HTTP status
+		var providerErr *domain.ProviderError
+		if errors.As(err, &providerErr) {
+			s.writeGatewayError(w, err, "server_error")
+			return
+		}
 		s.writeSSEError(w, flusher, err)
 		return
 	}
+	setContextHeaders(w, domainReq.ContextAction)
+	setRoutingHeaders(w, domainReq.RoutingDecision)
+	setCacheHeaders(w, domainReq.CacheLookup)
+	setTransformHeaders(w, domainReq.OutputTransform)
 
-	id := fmt.Sprintf("chatcmpl-%s", uuid.New().String())
+	id := completionID(domainReq.RequestID)
 	created := time.Now().Unix()
 	chunkCount := 0
+	var toolCalls toolCallChunker
 
 	// Extend the write deadline for the entire streaming response
 	// Set to 30 minutes to handle very long responses
@@ -1245,13 +2171,16 @@ func (s *Server) handleStreamingResponse(w http.ResponseWriter, r *http.Request,
 				Role: stringPtr("assistant"),
 			},
 		}},
+		Documents: req.retrieved,
 	}); err != nil {
 		slog.Error("Failed to write initial SSE chunk", "error", err)
 		return
 	}
+	defer s.trackStream()()
 
-	for event := range events {
+	for event := range sseEvents(r.Context(), w, flusher, events, sseKeepAliveInterval) {
 		chunkCount++
+		req.thread.observe(event)
 
 		// Extend write deadline every 50 chunks to prevent timeout during long streams
 		if chunkCount%50 == 0 {
@@ -1277,8 +2206,21 @@ func (s *Server) handleStreamingResponse(w http.ResponseWriter, r *http.Request,
 				}},
 			})
 
-		case domain.ToolCallEvent:
-			argsJSON, _ := json.Marshal(e.ToolCall.Function.Arguments)
+		case domain.ToolCallDelta, domain.ToolCallEvent:
+			if tc, ok := toolCalls.chunk(e); ok {
+				writeErr = s.writeSSEChunk(w, flusher, ChatCompletionChunk{
+					ID:      id,
+					Object:  "chat.completion.chunk",
+					Created: created,
+					Model:   req.Model,
+					Choices: []ChunkChoice{{
+						Index: 0,
+						Delta: Delta{ToolCalls: []ToolCallChunk{tc}},
+					}},
+				})
+			}
+
+		case domain.CitationEvent:
 			writeErr = s.writeSSEChunk(w, flusher, ChatCompletionChunk{
 				ID:      id,
 				Object:  "chat.completion.chunk",
@@ -1286,16 +2228,7 @@ func (s *Server) handleStreamingResponse(w http.ResponseWriter, r *http.Request,
 				Model:   req.Model,
 				Choices: []ChunkChoice{{
 					Index: 0,
-					Delta: Delta{
-						ToolCalls: []ToolCall{{
-							ID:   e.ToolCall.ID,
-							Type: "function",
-							Function: &FunctionCall{
-								Name:      e.ToolCall.Function.Name,
-								Arguments: string(argsJSON),
-							},
-						}},
-					},
+					Delta: Delta{Citations: citations([]domain.Citation{e.Citation})},
 				}},
 			})
 
@@ -1307,6 +2240,8 @@ func (s *Server) handleStreamingResponse(w http.ResponseWriter, r *http.Request,
 				reason = "length"
 			} else if e.Reason == domain.FinishReasonError {
 				reason = "error"
+			} else if e.Reason == domain.FinishReasonPolicyViolation {
+				reason = "policy_violation"
 			}
 			writeErr = s.writeSSEChunk(w, flusher, ChatCompletionChunk{
 				ID:      id,
@@ -1321,6 +2256,11 @@ func (s *Server) handleStreamingResponse(w http.ResponseWriter, r *http.Request,
 			})
 			slog.Debug("SSE stream finished", "chunks", chunkCount, "reason", reason)
 
+		case domain.ErrorEvent:
+			// The FinishEvent that follows closes the choice with reason "error"
+			slog.Warn("Provider error in stream", "code", e.Err.Code, "message", e.Err.Message)
+			writeErr = s.writeSSEChunk(w, flusher, ErrorResponse{Error: providerErrorDetail(e.Err)})
+
 		case domain.PolicyViolationEvent:
 			// Send error message to client as content and then finish with error
 			slog.Error("Policy violation in stream", "message", e.Message)
@@ -1359,25 +2299,36 @@ func (s *Server) handleStreamingResponse(w http.ResponseWriter, r *http.Request,
 			continue
 		}
 	}
+	if r.Context().Err() != nil {
+		slog.Info("Client disconnected, stream cancelled", "chunks", chunkCount)
+		return
+	}
 
 	// Send [DONE] marker
 	fmt.Fprintf(w, "data: [DONE]\n\n")
 	flusher.Flush()
 	slog.Debug("SSE stream complete", "total_chunks", chunkCount)
+	s.finishThreadTurn(r.Context(), req, nil)
 }
 
 // handleNonStreamingResponse handles non-streaming response
 func (s *Server) handleNonStreamingResponse(w http.ResponseWriter, r *http.Request, domainReq *domain.ChatRequest, req *ChatCompletionRequest) {
 	response, err := s.gateway.ChatComplete(r.Context(), domainReq)
+	setLimitHeaders(w, domainReq.Limits)
 	if err != nil {
-		s.writeError(w, http.StatusInternalServerError, "server_error", err.Error())
+		s.writeGatewayError(w, err, "server_error")
 		return
 	}
+	setContextHeaders(w, domainReq.ContextAction)
+	setRoutingHeaders(w, domainReq.RoutingDecision)
+	setCacheHeaders(w, domainReq.CacheLookup)
+	setTransformHeaders(w, domainReq.OutputTransform)
 
 	// Convert to OpenAI format
 	msg := ChatMessage{
-		Role:    "assistant",
-		Content: response.Content,
+		Role:      "assistant",
+		Content:   response.Content,
+		Citations: citations(response.Citations),
 	}
 
 	if len(response.ToolCalls) > 0 {
@@ -1400,7 +2351,7 @@ func (s *Server) handleNonStreamingResponse(w http.ResponseWriter, r *http.Reque
 	}
 
 	resp := ChatCompletionResponse{
-		ID:      fmt.Sprintf("chatcmpl-%s", uuid.New().String()),
+		ID:      completionID(domainReq.RequestID),
 		Object:  "chat.completion",
 		Created: time.Now().Unix(),
 		Model:   req.Model,
@@ -1409,6 +2360,7 @@ func (s *Server) handleNonStreamingResponse(w http.ResponseWriter, r *http.Reque
 			Message:      msg,
 			FinishReason: reason,
 		}},
+		Documents: req.retrieved,
 	}
 
 	if response.Usage != nil {
@@ -1419,11 +2371,13 @@ func (s *Server) handleNonStreamingResponse(w http.ResponseWriter, r *http.Reque
 		}
 	}
 
+	setETag(w, req, response)
 	s.writeJSON(w, http.StatusOK, resp)
+	s.finishThreadTurn(r.Context(), req, response)
 }
 
 // handleEmbeddings handles POST /v1/embeddings
-func (s *Server) handleEmbeddings(w http.ResponseWriter, r *http.Request, tenantObj *domain.Tenant) {
+func (s *Server) handleEmbeddings(w http.ResponseWriter, r *http.Request, auth *AuthContext) {
 	var req EmbeddingsRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		s.writeError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON body")
@@ -1442,21 +2396,52 @@ func (s *Server) handleEmbeddings(w http.ResponseWriter, r *http.Request, tenant
 			}
 		}
 	}
+	if len(texts) == 0 {
+		s.writeError(w, http.StatusBadRequest, "invalid_request", "input must be a string or an array of strings")
+		return
+	}
+
+	embedReq := &domain.EmbeddingRequest{
+		Model:      req.Model,
+		Input:      texts,
+		Dimensions: req.Dimensions,
+		RequestID:  uuid.New().String(),
+	}
+	if auth.Tenant != nil {
+		embedReq.TenantID = auth.Tenant.ID
+	}
+	if auth.APIKey != nil {
+		embedReq.APIKeyID = auth.APIKey.ID
+		embedReq.ProjectID = auth.APIKey.ProjectID
+	}
 
-	tenantID := ""
-	if tenantObj != nil {
-		tenantID = tenantObj.ID
+	policyResult, dimensionsCap, err := s.enforceEmbeddingPolicies(r.Context(), embedReq, auth)
+	if err != nil {
+		s.writePolicyViolationError(w, err)
+		return
 	}
+	setPolicyHeaders(w, policyResult)
 
-	embeddings, tokens, err := s.gateway.Embed(r.Context(), req.Model, texts, req.Dimensions, tenantID)
+	resp, err := s.gateway.Embed(r.Context(), embedReq)
 	if err != nil {
-		s.writeError(w, http.StatusInternalServerError, "server_error", err.Error())
+		s.writeGatewayError(w, err, "server_error")
+		return
+	}
+	// Providers that ignore the dimensions parameter mustn't get past the cap
+	if dimensionsCap > 0 && len(resp.Embeddings) > 0 && len(resp.Embeddings[0]) > int(dimensionsCap) {
+		s.writeError(w, http.StatusBadRequest, "dimensions_not_allowed",
+			fmt.Sprintf("%s returns %d dimensions; at most %d are allowed", resp.Model, len(resp.Embeddings[0]), dimensionsCap))
 		return
 	}
 
+	w.Header().Set("X-ModelGate-Provider", string(resp.Provider))
+	if resp.FallbackFrom != "" {
+		w.Header().Set("X-ModelGate-Fallback-From", resp.FallbackFrom)
+	}
+
 	// Convert to OpenAI format
 	var data []EmbeddingData
-	for i, emb := range embeddings {
+	for i, emb := range resp.Embeddings {
 		data = append(data, EmbeddingData{
 			Object:    "embedding",
 			Embedding: emb,
@@ -1467,35 +2452,102 @@ func (s *Server) handleEmbeddings(w http.ResponseWriter, r *http.Request, tenant
 	s.writeJSON(w, http.StatusOK, EmbeddingsResponse{
 		Object: "list",
 		Data:   data,
-		Model:  req.Model,
+		Model:  resp.Model,
 		Usage: EmbeddingUsage{
-			PromptTokens: int(tokens),
-			TotalTokens:  int(tokens),
+			PromptTokens: int(resp.PromptTokens),
+			TotalTokens:  int(resp.PromptTokens),
 		},
 	})
 }
 
-// handleListModelsFiltered handles GET /v1/models with role-based filtering
+// enforceEmbeddingPolicies checks an embedding request against each of the
+// caller's roles and the project and key budgets. Requests that don't ask for
+// dimensions get the roles' cap, which is returned so the response can be
+// checked against it.
+func (s *Server) enforceEmbeddingPolicies(ctx context.Context, req *domain.EmbeddingRequest, auth *AuthContext) (*ToolPolicyResult, int32, error) {
+	rolePolicies, err := s.requireRolePolicies(ctx, auth)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, rolePolicy := range rolePolicies {
+		if err := policy.CheckEmbeddingRequest(req, &rolePolicy.ModelRestriction); err != nil {
+			return nil, 0, err
+		}
+	}
+	dimensionsCap := policy.EmbeddingDimensionsCap(rolePolicies)
+	if req.Dimensions == nil && dimensionsCap > 0 {
+		req.Dimensions = &dimensionsCap
+	}
+
+	warning, err := s.checkProjectBudget(ctx, auth.APIKey)
+	if err != nil {
+		return nil, 0, err
+	}
+	keyCheck, err := s.checkKeyBudget(ctx, auth.APIKey)
+	if err != nil {
+		return nil, 0, err
+	}
+	if keyCheck != nil && keyCheck.Warning != "" {
+		warning = strings.TrimPrefix(warning+"; "+keyCheck.Warning, "; ")
+	}
+	result := &ToolPolicyResult{BudgetWarning: warning}
+	if keyCheck != nil {
+		result.KeyBudget = keyCheck.Status
+	}
+	return result, dimensionsCap, nil
+}
+
+// handleListModelsFiltered handles GET /v1/models with role-based filtering.
+// Query parameters narrow the list further (see parseModelFilter).
 func (s *Server) handleListModelsFiltered(w http.ResponseWriter, r *http.Request, auth *AuthContext) {
+	filter, err := parseModelFilter(r.URL.Query())
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	_, filteredModels, err := s.modelsForKey(r.Context(), auth)
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+
+	var data []ModelData
+	for _, m := range filteredModels {
+		if filter != nil && !filter.Matches(s.gateway.DescribeModel(r.Context(), m)) {
+			continue
+		}
+		data = append(data, ModelData{
+			ID:      m.ID,
+			Object:  "model",
+			Created: 1234567890,
+			OwnedBy: string(m.Provider),
+		})
+	}
+
+	s.writeJSON(w, http.StatusOK, ModelsResponse{
+		Object: "list",
+		Data:   data,
+	})
+}
+
+// modelsForKey returns the models of the catalog and those the API key's
+// role or group allows
+func (s *Server) modelsForKey(ctx context.Context, auth *AuthContext) (all, allowed []domain.ModelInfo, err error) {
 	// Load models from tenant database (single-tenant mode)
 	var models []domain.ModelInfo
 	if s.pgStore != nil {
 		tenantStore := s.pgStore.TenantStore()
-		var err error
-		models, err = tenantStore.ListAvailableModelsForAPI(r.Context())
+		models, err = tenantStore.ListAvailableModelsForAPI(ctx)
 		if err != nil {
-			s.writeError(w, http.StatusInternalServerError, "server_error", "Failed to list models")
-			return
+			return nil, nil, fmt.Errorf("failed to list models")
 		}
 	}
 
 	// If no models from database, fall back to gateway
 	if len(models) == 0 {
-		var err error
-		models, _, err = s.gateway.ListModels(r.Context(), "")
+		models, _, err = s.gateway.ListModels(ctx, "")
 		if err != nil {
-			s.writeError(w, http.StatusInternalServerError, "server_error", err.Error())
-			return
+			return nil, nil, err
 		}
 	}
 
@@ -1508,7 +2560,7 @@ func (s *Server) handleListModelsFiltered(w http.ResponseWriter, r *http.Request
 
 		// Case 1: API key has a direct role assignment
 		if auth.APIKey.RoleID != "" {
-			role, err := tenantStore.GetRole(r.Context(), auth.APIKey.RoleID)
+			role, err := tenantStore.GetRole(ctx, auth.APIKey.RoleID)
 			if err == nil && role != nil && role.Policy != nil {
 				restrictions = append(restrictions, &role.Policy.ModelRestriction)
 			}
@@ -1516,7 +2568,7 @@ func (s *Server) handleListModelsFiltered(w http.ResponseWriter, r *http.Request
 
 		// Case 2: API key has a group assignment (inherits from ALL roles in the group)
 		if auth.APIKey.GroupID != "" {
-			groupRoles, err := tenantStore.GetGroupRoles(r.Context(), auth.APIKey.GroupID)
+			groupRoles, err := tenantStore.GetGroupRoles(ctx, auth.APIKey.GroupID)
 			if err == nil {
 				for _, role := range groupRoles {
 					if role.Policy != nil {
@@ -1531,21 +2583,7 @@ func (s *Server) handleListModelsFiltered(w http.ResponseWriter, r *http.Request
 			filteredModels = filterModelsByPolicies(models, restrictions)
 		}
 	}
-
-	var data []ModelData
-	for _, m := range filteredModels {
-		data = append(data, ModelData{
-			ID:      m.ID,
-			Object:  "model",
-			Created: 1234567890,
-			OwnedBy: string(m.Provider),
-		})
-	}
-
-	s.writeJSON(w, http.StatusOK, ModelsResponse{
-		Object: "list",
-		Data:   data,
-	})
+	return models, filteredModels, nil
 }
 
 // filterModelsByPolicies filters models based on multiple role policies (for group memberships)
@@ -1618,36 +2656,23 @@ func filterModelsByPolicy(models []domain.ModelInfo, restrictions *domain.ModelR
 	return filtered
 }
 
-// handleGetModelFiltered handles GET /v1/models/{model} with role-based access check
+// handleGetModelFiltered handles GET /v1/models/{model} with role-based
+// access check, and GET /v1/models/{model}/details with the model's full
+// catalog entry
 func (s *Server) handleGetModelFiltered(w http.ResponseWriter, r *http.Request, auth *AuthContext) {
-	modelID := r.PathValue("model")
-	tenantID := ""
-	roleID := ""
-	if auth.Tenant != nil {
-		tenantID = auth.Tenant.ID
-	}
-	if auth.APIKey != nil {
-		roleID = auth.APIKey.RoleID
-	}
-
-	// Get all models
-	models, _, err := s.gateway.ListModels(r.Context(), tenantID)
+	modelID, details := strings.CutSuffix(r.PathValue("model"), "/details")
+	models, filteredModels, err := s.modelsForKey(r.Context(), auth)
 	if err != nil {
 		s.writeError(w, http.StatusInternalServerError, "server_error", err.Error())
 		return
 	}
 
-	// Filter models by role
-	filteredModels := models
-	if roleID != "" && tenantID != "" {
-		filteredModels, err = s.gateway.GetAllowedModelsForRole(r.Context(), tenantID, roleID, models)
-		if err != nil {
-			filteredModels = models
-		}
-	}
-
 	for _, m := range filteredModels {
 		if m.ID == modelID {
+			if details {
+				s.writeJSON(w, http.StatusOK, modelDetail(s.gateway.DescribeModel(r.Context(), m)))
+				return
+			}
 			s.writeJSON(w, http.StatusOK, ModelData{
 				ID:      m.ID,
 				Object:  "model",
@@ -1674,9 +2699,25 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	s.writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
 }
 
-// handleReady handles readiness check
+// handleReady handles readiness check. Without a checker the server is
+// always ready; otherwise it is unready (503) while a critical dependency is
+// down, and ready but flagged degraded while another one is impaired.
 func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
-	s.writeJSON(w, http.StatusOK, map[string]string{"status": "ready"})
+	// A draining instance leaves the load balancer's rotation
+	if status := s.DrainStatus(); status.Draining {
+		s.writeJSON(w, http.StatusServiceUnavailable, map[string]any{"status": "draining", "drain": status})
+		return
+	}
+	if s.readiness == nil {
+		s.writeJSON(w, http.StatusOK, map[string]string{"status": "ready"})
+		return
+	}
+	report := s.readiness.Check(r.Context())
+	status := http.StatusOK
+	if report.Status == readiness.NotReady {
+		status = http.StatusServiceUnavailable
+	}
+	s.writeJSON(w, status, report)
 }
 
 // Helper methods
@@ -1696,6 +2737,64 @@ func (s *Server) writeError(w http.ResponseWriter, status int, errType, message
 	})
 }
 
+// writeGatewayError writes an error returned by the gateway. Provider failures
+// keep their normalized status, type and code; anything else is a 500 of errType.
+func (s *Server) writeGatewayError(w http.ResponseWriter, err error, errType string) {
+	var providerErr *domain.ProviderError
+	if errors.As(err, &providerErr) {
+		if providerErr.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(providerErr.RetryAfter.Seconds()))))
+		}
+		s.writeJSON(w, providerErr.Status, ErrorResponse{Error: providerErrorDetail(providerErr)})
+		return
+	}
+	s.writeError(w, http.StatusInternalServerError, errType, err.Error())
+}
+
+func providerErrorDetail(e *domain.ProviderError) ErrorDetail {
+	return ErrorDetail{
+		Type:    e.Type,
+		Message: e.Message,
+		Code:    e.Code,
+	}
+}
+
+// toolCallChunker turns tool call events into OpenAI tool call chunks.
+// Deltas are passed on as they arrive, and the complete call that follows
+// them is skipped. A complete call that wasn't streamed, from a provider
+// that returns whole calls, is sent as a single chunk at the next index.
+type toolCallChunker struct {
+	next int
+}
+
+// chunk returns the tool call chunk for a ToolCallDelta or ToolCallEvent,
+// and false when there is nothing to send
+func (t *toolCallChunker) chunk(event domain.StreamEvent) (ToolCallChunk, bool) {
+	switch e := event.(type) {
+	case domain.ToolCallDelta:
+		t.next = max(t.next, e.Index+1)
+		tc := ToolCallChunk{Index: e.Index, Function: &FunctionCallChunk{Arguments: e.Delta}}
+		if e.ID != "" {
+			tc.ID, tc.Type, tc.Function.Name = e.ID, "function", e.Name
+		}
+		return tc, true
+	case domain.ToolCallEvent:
+		if e.Streamed {
+			return ToolCallChunk{}, false
+		}
+		argsJSON, _ := json.Marshal(e.ToolCall.Function.Arguments)
+		tc := ToolCallChunk{
+			Index:    t.next,
+			ID:       e.ToolCall.ID,
+			Type:     "function",
+			Function: &FunctionCallChunk{Name: e.ToolCall.Function.Name, Arguments: string(argsJSON)},
+		}
+		t.next++
+		return tc, true
+	}
+	return ToolCallChunk{}, false
+}
+
 func (s *Server) writeSSEChunk(w io.Writer, flusher http.Flusher, chunk any) error {
 	data, _ := json.Marshal(chunk)
 	_, err := fmt.Fprintf(w, "data: %s\n\n", data)
@@ -1711,14 +2810,71 @@ func (s *Server) writeSSEError(w io.Writer, flusher http.Flusher, err error) {
 	flusher.Flush()
 }
 
+// parseCacheControl reads the X-ModelGate-Cache header: "bypass" skips the
+// semantic cache, "refresh" skips the lookup but caches the new response
+func parseCacheControl(value string) (domain.CacheControl, error) {
+	switch cc := domain.CacheControl(strings.ToLower(strings.TrimSpace(value))); cc {
+	case "", domain.CacheControlBypass, domain.CacheControlRefresh:
+		return cc, nil
+	default:
+		return "", fmt.Errorf("invalid X-ModelGate-Cache header %q: expected bypass or refresh", value)
+	}
+}
+
+// maxConversationIDLength bounds the X-ModelGate-Conversation-ID header
+const maxConversationIDLength = 256
+
+// parseConversationID reads the X-ModelGate-Conversation-ID header, which ties
+// the turns of a conversation together for sticky routing
+func parseConversationID(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if len(value) > maxConversationIDLength {
+		return "", fmt.Errorf("invalid X-ModelGate-Conversation-ID header: longer than %d characters", maxConversationIDLength)
+	}
+	return value, nil
+}
+
+// maxRequestTimeout bounds the X-ModelGate-Timeout-Ms header
+const maxRequestTimeout = time.Hour
+
+// parseRequestLimits reads the X-ModelGate-Timeout-Ms header, a hard deadline
+// for the request in milliseconds, and X-ModelGate-Max-Cost, a cap in USD on
+// what the request may cost
+func parseRequestLimits(h http.Header) (domain.RequestLimits, error) {
+	var limits domain.RequestLimits
+	if v := strings.TrimSpace(h.Get("X-ModelGate-Timeout-Ms")); v != "" {
+		ms, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || ms <= 0 || time.Duration(ms)*time.Millisecond > maxRequestTimeout {
+			return limits, fmt.Errorf("invalid X-ModelGate-Timeout-Ms header %q: expected milliseconds between 1 and %d", v, maxRequestTimeout.Milliseconds())
+		}
+		limits.Timeout = time.Duration(ms) * time.Millisecond
+	}
+	if v := strings.TrimSpace(h.Get("X-ModelGate-Max-Cost")); v != "" {
+		cost, err := strconv.ParseFloat(v, 64)
+		if err != nil || !(cost > 0) || math.IsInf(cost, 0) {
+			return limits, fmt.Errorf("invalid X-ModelGate-Max-Cost header %q: expected a positive amount in USD", v)
+		}
+		limits.MaxCostUSD = cost
+	}
+	return limits, nil
+}
+
 func (s *Server) convertChatRequest(req *ChatCompletionRequest) *domain.ChatRequest {
 	domainReq := &domain.ChatRequest{
 		Model:       req.Model,
 		Temperature: req.Temperature,
+		TopP:        req.TopP,
 		MaxTokens:   req.MaxTokens,
 		Streaming:   req.Stream,
 		RequestID:   uuid.New().String(),
 	}
+	domainReq.ResponseFormat, _ = parseResponseFormat(req.ResponseFormat) // Validated by the handlers
+	domainReq.ToolChoice, _ = parseToolChoice(req.ToolChoice)
+	domainReq.ParallelToolCalls = req.ParallelToolCalls
+	for _, doc := range req.Documents {
+		domainReq.Documents = append(domainReq.Documents, domain.Document{ID: doc.ID, Text: doc.Text, AdditionalProps: doc.Fields,
+			Collection: doc.Collection})
+	}
 
 	// Convert messages
 	for _, msg := range req.Messages {
@@ -1754,6 +2910,10 @@ func (s *Server) convertChatRequest(req *ChatCompletionRequest) *domain.ChatRequ
 									ImageURL: imgURL["url"].(string),
 								})
 							}
+						case "file":
+							if block, ok := parseFilePart(cm); ok {
+								domainMsg.Content = append(domainMsg.Content, block)
+							}
 						}
 					}
 				}
@@ -1815,28 +2975,36 @@ func (s *Server) handleResponses(w http.ResponseWriter, r *http.Request, auth *A
 	domainReq := s.convertResponsesRequest(&req, auth)
 
 	// Enforce policies (reuse existing policy engine)
-	toolResult, err := s.enforcePoliciesForRequest(r.Context(), &domain.ChatRequest{
-		Model:    domainReq.Model,
-		Messages: domainReq.Messages,
-		APIKeyID: domainReq.APIKeyID,
-		RoleID:   domainReq.RoleID,
-		GroupID:  domainReq.GroupID,
-	}, auth)
+	enforced := &domain.ChatRequest{
+		Model:       domainReq.Model,
+		Messages:    domainReq.Messages,
+		Temperature: domainReq.Temperature,
+		TopP:        domainReq.TopP,
+		MaxTokens:   domainReq.MaxTokens,
+		APIKeyID:    domainReq.APIKeyID,
+		RoleID:      domainReq.RoleID,
+		GroupID:     domainReq.GroupID,
+	}
+	toolResult, err := s.enforcePoliciesForRequest(r.Context(), enforced, auth)
 	if err != nil {
 		s.writePolicyViolationError(w, err)
 		return
 	}
+	domainReq.Temperature, domainReq.TopP, domainReq.MaxTokens = enforced.Temperature, enforced.TopP, enforced.MaxTokens
 
 	// Add headers for removed tools (if any)
-	if toolResult != nil && len(toolResult.RemovedTools) > 0 {
-		w.Header().Set("X-ModelGate-Removed-Tools", strings.Join(toolResult.RemovedTools, ","))
-		w.Header().Set("X-ModelGate-Warning", fmt.Sprintf("%d tool(s) removed from request", len(toolResult.RemovedTools)))
-	}
+	setPolicyHeaders(w, toolResult)
 
 	// Call responses service
 	resp, err := s.responsesService.GenerateResponse(r.Context(), domainReq)
 	if err != nil {
 		slog.Error("responses generation failed", "error", err, "model", domainReq.Model)
+		var repairErr *responses.RepairError
+		if errors.As(err, &repairErr) {
+			w.Header().Set("X-ModelGate-Retry-Count", strconv.Itoa(repairErr.Attempts-1))
+			s.writeError(w, http.StatusUnprocessableEntity, "schema_validation_failed", err.Error())
+			return
+		}
 		s.writeError(w, http.StatusInternalServerError, "generation_error", err.Error())
 		return
 	}
@@ -1853,6 +3021,7 @@ func (s *Server) handleResponses(w http.ResponseWriter, r *http.Request, auth *A
 			CompletionTokens: resp.Usage.CompletionTokens,
 			TotalTokens:      resp.Usage.TotalTokens,
 		},
+		Metadata: resp.Metadata,
 	}
 
 	// Add metadata headers
@@ -1863,6 +3032,9 @@ func (s *Server) handleResponses(w http.ResponseWriter, r *http.Request, auth *A
 		if resp.Metadata.RetryCount > 0 {
 			w.Header().Set("X-ModelGate-Retry-Count", fmt.Sprintf("%d", resp.Metadata.RetryCount))
 		}
+		if resp.Metadata.RepairModel != "" {
+			w.Header().Set("X-ModelGate-Repair-Model", resp.Metadata.RepairModel)
+		}
 	}
 
 	// Record metrics (reuse existing telemetry)
@@ -1953,21 +3125,46 @@ func stringPtr(s string) *string {
 
 // Start starts the HTTP server
 func (s *Server) Start(ctx context.Context, addr string) error {
+	cfg := s.config.Load().Server
+	tc, err := tlsConfig(cfg)
+	if err != nil {
+		return err
+	}
+	if s.domains.TerminatesTLS() {
+		tc = s.domains.TLSConfig(tc)
+		if s.domains.ACMEHTTPPort() > 0 {
+			go s.serveACMEChallenges(ctx, addr)
+		}
+	}
 	server := &http.Server{
 		Addr:         addr,
 		Handler:      s.Handler(),
-		ReadTimeout:  s.config.Server.ReadTimeout,
-		WriteTimeout: s.config.Server.WriteTimeout,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		TLSConfig:    tc,
 	}
 
+	// Shutdown waits for the handlers still running. Drain first so that
+	// data-plane requests are done by then.
+	shutdownDone := make(chan struct{})
 	go func() {
+		defer close(shutdownDone)
 		<-ctx.Done()
 		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 		server.Shutdown(shutdownCtx)
 	}()
 
-	return server.ListenAndServe()
+	if tc != nil {
+		slog.Info("Serving HTTPS", "addr", addr, "client_auth", cfg.ClientAuth)
+		err = server.ListenAndServeTLS("", "")
+	} else {
+		err = server.ListenAndServe()
+	}
+	if err == http.ErrServerClosed {
+		<-shutdownDone
+	}
+	return err
 }
 
 // hashAPIKey creates a SHA-256 hash of the API key
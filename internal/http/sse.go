@@ -0,0 +1,52 @@
+package http
+
+import (
+	"context"
+	"io"
+	"iter"
+	"net/http"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+// sseKeepAliveInterval is how long a stream may stay silent before a keep-alive
+// comment is sent, so proxies don't close the idle connection
+const sseKeepAliveInterval = 15 * time.Second
+
+// sseEvents yields a stream's events as they arrive. While the provider is
+// silent, for instance during a long reasoning pause, it writes an SSE comment
+// every interval. It stops when the client disconnects; the gateway then
+// cancels the provider stream, and the events left are drained in the
+// background so the gateway never blocks.
+func sseEvents(ctx context.Context, w io.Writer, flusher http.Flusher, events <-chan domain.StreamEvent, interval time.Duration) iter.Seq[domain.StreamEvent] {
+	return func(yield func(domain.StreamEvent) bool) {
+		keepAlive := time.NewTicker(interval)
+		defer keepAlive.Stop()
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				if !yield(event) {
+					go drainEvents(events)
+					return
+				}
+				keepAlive.Reset(interval)
+			case <-keepAlive.C:
+				if _, err := io.WriteString(w, ": keepalive\n\n"); err == nil {
+					flusher.Flush()
+				}
+			case <-ctx.Done():
+				go drainEvents(events)
+				return
+			}
+		}
+	}
+}
+
+func drainEvents(events <-chan domain.StreamEvent) {
+	for range events {
+	}
+}
@@ -0,0 +1,40 @@
+package http
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+func TestSSEEventsKeepAliveAndDisconnect(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w := httptest.NewRecorder()
+	events := make(chan domain.StreamEvent)
+
+	go func() {
+		events <- domain.TextChunk{Content: "thinking done"}
+		// A silent stretch, then the client goes away mid-stream
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+		time.Sleep(20 * time.Millisecond)
+		events <- domain.TextChunk{Content: "drained, never delivered"}
+		close(events)
+	}()
+
+	var got []domain.StreamEvent
+	for event := range sseEvents(ctx, w, w, events, 10*time.Millisecond) {
+		got = append(got, event)
+	}
+
+	if len(got) != 1 {
+		t.Fatalf("expected only the event sent before the disconnect, got %v", got)
+	}
+	if !strings.Contains(w.Body.String(), ": keepalive\n\n") {
+		t.Fatalf("expected keep-alive comments during the silence, got %q", w.Body.String())
+	}
+}
@@ -0,0 +1,330 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"modelgate/internal/domain"
+	"modelgate/internal/threads"
+)
+
+// CreateThreadRequest is the body for POST /v1/threads
+type CreateThreadRequest struct {
+	Messages []ChatMessage     `json:"messages,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// AppendThreadMessagesRequest is the body for POST /v1/threads/{thread_id}/messages.
+// Assistants API clients send a single message as role and content instead.
+type AppendThreadMessagesRequest struct {
+	Messages []ChatMessage `json:"messages"`
+	AssistantMessageRequest
+}
+
+// ThreadResponse is the thread object
+type ThreadResponse struct {
+	ID           string            `json:"id"`
+	Object       string            `json:"object"` // "thread"
+	CreatedAt    int64             `json:"created_at"`
+	ExpiresAt    int64             `json:"expires_at"`
+	MessageCount int               `json:"message_count"`
+	Bytes        int64             `json:"bytes"`
+	Metadata     map[string]string `json:"metadata"`
+}
+
+// ThreadMessageResponse is a message stored in a thread
+type ThreadMessageResponse struct {
+	ID        string `json:"id"`
+	Object    string `json:"object"` // "thread.message"
+	ThreadID  string `json:"thread_id"`
+	CreatedAt int64  `json:"created_at"`
+	ChatMessage
+}
+
+// ThreadMessageListResponse lists the messages of a thread, oldest first
+type ThreadMessageListResponse struct {
+	Object string                  `json:"object"` // "list"
+	Data   []ThreadMessageResponse `json:"data"`
+}
+
+// threadTurn collects one chat completion made against a thread so the new
+// messages and the assistant's reply can be appended once it finishes
+type threadTurn struct {
+	threadID  string
+	apiKeyID  string
+	messages  []domain.Message // The messages sent in the request, without the history
+	content   strings.Builder
+	toolCalls []domain.ToolCall
+	failed    bool
+}
+
+// observe records a stream event. Safe to call on a nil turn.
+func (t *threadTurn) observe(event domain.StreamEvent) {
+	if t == nil {
+		return
+	}
+	switch e := event.(type) {
+	case domain.TextChunk:
+		t.content.WriteString(e.Content)
+	case domain.ToolCallEvent:
+		t.toolCalls = append(t.toolCalls, e.ToolCall)
+	case domain.FinishEvent:
+		if e.Reason == domain.FinishReasonError || e.Reason == domain.FinishReasonPolicyViolation {
+			t.failed = true
+		}
+	case domain.ErrorEvent, domain.PolicyViolationEvent:
+		t.failed = true
+	}
+}
+
+// SetThreadService enables the threads endpoints and thread_id on chat completions
+func (s *Server) SetThreadService(svc *threads.Service) {
+	s.threadService = svc
+	s.mux = http.NewServeMux()
+	s.setupRoutes()
+}
+
+// handleCreateThread handles POST /v1/threads
+func (s *Server) handleCreateThread(w http.ResponseWriter, r *http.Request, auth *AuthContext) {
+	var req CreateThreadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON body")
+		return
+	}
+	msgs, ok := s.threadMessagesFromRequest(w, req.Messages)
+	if !ok {
+		return
+	}
+
+	thread, err := s.threadService.Create(r.Context(), authAPIKeyID(auth), msgs, req.Metadata)
+	if err != nil {
+		s.writeThreadError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, toThreadResponse(thread))
+}
+
+// handleGetThread handles GET /v1/threads/{thread_id}
+func (s *Server) handleGetThread(w http.ResponseWriter, r *http.Request, auth *AuthContext) {
+	thread, err := s.threadService.Get(r.Context(), r.PathValue("thread_id"), authAPIKeyID(auth))
+	if err != nil {
+		s.writeThreadError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, toThreadResponse(thread))
+}
+
+// handleDeleteThread handles DELETE /v1/threads/{thread_id}
+func (s *Server) handleDeleteThread(w http.ResponseWriter, r *http.Request, auth *AuthContext) {
+	threadID := r.PathValue("thread_id")
+	if err := s.threadService.Delete(r.Context(), threadID, authAPIKeyID(auth)); err != nil {
+		s.writeThreadError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, map[string]any{
+		"id":      threadID,
+		"object":  "thread.deleted",
+		"deleted": true,
+	})
+}
+
+// handleAppendThreadMessages handles POST /v1/threads/{thread_id}/messages
+func (s *Server) handleAppendThreadMessages(w http.ResponseWriter, r *http.Request, auth *AuthContext) {
+	var req AppendThreadMessagesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON body")
+		return
+	}
+	single := len(req.Messages) == 0 && req.Role != ""
+	var msgs []domain.Message
+	var ok bool
+	if single {
+		msgs, ok = s.assistantMessages(w, []AssistantMessageRequest{req.AssistantMessageRequest})
+	} else {
+		msgs, ok = s.threadMessagesFromRequest(w, req.Messages)
+	}
+	if !ok {
+		return
+	}
+
+	threadID := r.PathValue("thread_id")
+	thread, err := s.threadService.Append(r.Context(), threadID, authAPIKeyID(auth), msgs)
+	if err != nil {
+		s.writeThreadError(w, err)
+		return
+	}
+	if !single {
+		s.writeJSON(w, http.StatusOK, toThreadResponse(thread))
+		return
+	}
+
+	// The Assistants API answers with the message that was added
+	stored, err := s.threadService.Messages(r.Context(), threadID, authAPIKeyID(auth))
+	if err != nil || len(stored) == 0 {
+		s.writeThreadError(w, err)
+		return
+	}
+	msg, _ := toAssistantMessage(stored[len(stored)-1])
+	s.writeJSON(w, http.StatusOK, msg)
+}
+
+// handleListThreadMessages handles GET /v1/threads/{thread_id}/messages
+func (s *Server) handleListThreadMessages(w http.ResponseWriter, r *http.Request, auth *AuthContext) {
+	msgs, err := s.threadService.Messages(r.Context(), r.PathValue("thread_id"), authAPIKeyID(auth))
+	if err != nil {
+		s.writeThreadError(w, err)
+		return
+	}
+	if assistantsAPI(r) {
+		s.writeAssistantMessages(w, r, msgs)
+		return
+	}
+
+	resp := ThreadMessageListResponse{Object: "list", Data: make([]ThreadMessageResponse, 0, len(msgs))}
+	for _, m := range msgs {
+		resp.Data = append(resp.Data, ThreadMessageResponse{
+			ID:          m.ID,
+			Object:      "thread.message",
+			ThreadID:    m.ThreadID,
+			CreatedAt:   m.CreatedAt.Unix(),
+			ChatMessage: toChatMessage(m.Message),
+		})
+	}
+	s.writeJSON(w, http.StatusOK, resp)
+}
+
+// beginThreadTurn prepends a thread's history to a chat request. It returns
+// false after writing an error if the thread cannot be used.
+func (s *Server) beginThreadTurn(w http.ResponseWriter, r *http.Request, req *ChatCompletionRequest, domainReq *domain.ChatRequest, auth *AuthContext) bool {
+	if req.ThreadID == "" {
+		return true
+	}
+	if s.threadService == nil {
+		s.writeError(w, http.StatusBadRequest, "invalid_request", "Threads are not enabled")
+		return false
+	}
+
+	apiKeyID := authAPIKeyID(auth)
+	history, err := s.threadService.History(r.Context(), req.ThreadID, apiKeyID, domainReq.Messages)
+	if err != nil {
+		s.writeThreadError(w, err)
+		return false
+	}
+
+	req.thread = &threadTurn{threadID: req.ThreadID, apiKeyID: apiKeyID, messages: domainReq.Messages}
+	domainReq.Messages = append(history, domainReq.Messages...)
+	return true
+}
+
+// finishThreadTurn appends the request's messages and the assistant's reply
+// to the thread. reply is nil for streams, whose reply was collected by observe.
+func (s *Server) finishThreadTurn(ctx context.Context, req *ChatCompletionRequest, reply *domain.ChatResponse) {
+	turn := req.thread
+	if turn == nil || turn.failed {
+		return
+	}
+
+	assistant := domain.Message{Role: "assistant"}
+	if reply != nil {
+		if reply.Content != "" {
+			assistant.Content = []domain.ContentBlock{{Type: "text", Text: reply.Content}}
+		}
+		assistant.ToolCalls = reply.ToolCalls
+	} else {
+		if turn.content.Len() > 0 {
+			assistant.Content = []domain.ContentBlock{{Type: "text", Text: turn.content.String()}}
+		}
+		assistant.ToolCalls = turn.toolCalls
+	}
+
+	// The client may have gone away after the reply was produced; keep the turn anyway
+	msgs := append(turn.messages, assistant)
+	if _, err := s.threadService.Append(context.WithoutCancel(ctx), turn.threadID, turn.apiKeyID, msgs); err != nil {
+		slog.Warn("Failed to append chat turn to thread", "thread_id", turn.threadID, "error", err)
+	}
+}
+
+// threadMessagesFromRequest converts request messages to domain messages. It
+// returns false after writing an error if a message cannot be stored.
+func (s *Server) threadMessagesFromRequest(w http.ResponseWriter, msgs []ChatMessage) ([]domain.Message, bool) {
+	for _, m := range msgs {
+		if m.Role == "system" {
+			s.writeError(w, http.StatusBadRequest, "invalid_request", "System messages are not stored in threads; send them with each chat completion")
+			return nil, false
+		}
+	}
+	return s.convertChatRequest(&ChatCompletionRequest{Messages: msgs}).Messages, true
+}
+
+// writeThreadError maps threads service errors to HTTP responses
+func (s *Server) writeThreadError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, threads.ErrThreadNotFound):
+		s.writeError(w, http.StatusNotFound, "not_found", err.Error())
+	case errors.Is(err, threads.ErrThreadFull):
+		s.writeError(w, http.StatusBadRequest, "thread_full", err.Error())
+	case errors.Is(err, threads.ErrInvalidMessage):
+		s.writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+	default:
+		slog.Error("Thread request failed", "error", err)
+		s.writeError(w, http.StatusInternalServerError, "server_error", "Internal server error")
+	}
+}
+
+func toThreadResponse(thread *domain.Thread) ThreadResponse {
+	metadata := thread.Metadata
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+	return ThreadResponse{
+		ID:           thread.ID,
+		Object:       "thread",
+		CreatedAt:    thread.CreatedAt.Unix(),
+		ExpiresAt:    thread.ExpiresAt.Unix(),
+		MessageCount: thread.MessageCount,
+		Bytes:        thread.SizeBytes,
+		Metadata:     metadata,
+	}
+}
+
+// toChatMessage converts a stored message back to the OpenAI wire format.
+// Text-only content is returned as a plain string.
+func toChatMessage(m domain.Message) ChatMessage {
+	msg := ChatMessage{Role: m.Role, ToolCallID: m.ToolCallID}
+
+	textOnly := true
+	var text strings.Builder
+	var parts []map[string]any
+	for _, block := range m.Content {
+		switch block.Type {
+		case "text":
+			text.WriteString(block.Text)
+			parts = append(parts, map[string]any{"type": "text", "text": block.Text})
+		case "image":
+			textOnly = false
+			parts = append(parts, map[string]any{"type": "image_url", "image_url": map[string]any{"url": block.ImageURL}})
+		}
+	}
+	if textOnly {
+		msg.Content = text.String()
+	} else {
+		msg.Content = parts
+	}
+
+	for _, tc := range m.ToolCalls {
+		argsJSON, _ := json.Marshal(tc.Function.Arguments)
+		msg.ToolCalls = append(msg.ToolCalls, ToolCall{
+			ID:   tc.ID,
+			Type: "function",
+			Function: &FunctionCall{
+				Name:      tc.Function.Name,
+				Arguments: string(argsJSON),
+			},
+		})
+	}
+	return msg
+}
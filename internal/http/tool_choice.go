@@ -0,0 +1,40 @@
+package http
+
+import (
+	"fmt"
+
+	"modelgate/internal/domain"
+)
+
+// parseToolChoice decodes a chat completion's tool_choice: "auto", "none",
+// "required", or {"type": "function", "function": {"name": ...}} to force one
+// function. It returns nil when the field is absent.
+func parseToolChoice(raw interface{}) (*domain.ToolChoice, error) {
+	switch v := raw.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		switch v {
+		case "auto", "none", "required":
+			return &domain.ToolChoice{Mode: v}, nil
+		}
+	case map[string]interface{}:
+		if v["type"] == "function" {
+			fn, _ := v["function"].(map[string]interface{})
+			if name, _ := fn["name"].(string); name != "" {
+				return &domain.ToolChoice{Mode: "required", Function: name}, nil
+			}
+			return nil, fmt.Errorf("tool_choice.function.name is required")
+		}
+	}
+	return nil, fmt.Errorf(`tool_choice must be "auto", "none", "required" or a function`)
+}
+
+// citations converts domain citations for a response
+func citations(list []domain.Citation) []Citation {
+	var out []Citation
+	for _, c := range list {
+		out = append(out, Citation{Start: c.Start, End: c.End, Text: c.Text, Sources: c.Sources})
+	}
+	return out
+}
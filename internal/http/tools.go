@@ -0,0 +1,277 @@
+package http
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+
+	"modelgate/internal/domain"
+	"modelgate/internal/mcp"
+	"modelgate/internal/policy"
+	"modelgate/internal/storage/postgres"
+)
+
+// Tool catalog sources
+const (
+	ToolSourceBuiltIn = "builtin" // Gateway tools and built-in available_tools entries
+	ToolSourceCatalog = "catalog" // Custom available_tools entries
+	ToolSourceMCP     = "mcp"     // Tools of connected MCP servers with ALLOW visibility
+)
+
+// ToolCatalogEntry is one tool the caller may use, in the chat completions tool format
+type ToolCatalogEntry struct {
+	Type      string                    `json:"type"` // "function"
+	Function  domain.FunctionDefinition `json:"function"`
+	Source    string                    `json:"source"`
+	Category  string                    `json:"category,omitempty"`
+	MCPServer string                    `json:"mcp_server,omitempty"`
+}
+
+// ToolCatalogResponse is the body of GET /v1/tools
+type ToolCatalogResponse struct {
+	Object string             `json:"object"` // "list"
+	Data   []ToolCatalogEntry `json:"data"`
+}
+
+// handleListTools returns the tools the caller's roles allow, after policy filtering.
+// Responses carry an ETag so agents can revalidate with If-None-Match.
+func (s *Server) handleListTools(w http.ResponseWriter, r *http.Request, auth *AuthContext) {
+	if !s.featureEnabled(r.Context(), auth, domain.FeatureToolCatalog) {
+		s.writeError(w, http.StatusNotFound, "not_found", "Tool catalog is not enabled")
+		return
+	}
+
+	catalog := ToolCatalogResponse{Object: "list", Data: []ToolCatalogEntry{}}
+
+	if s.pgStore != nil {
+		tenantStore := s.pgStore.TenantStore()
+		roles, err := s.rolesForAPIKey(r.Context(), auth, tenantStore)
+		if err != nil {
+			slog.Error("Failed to load roles for tool catalog", "error", err)
+			s.writeError(w, http.StatusInternalServerError, "server_error", "Failed to load tool policies")
+			return
+		}
+		if len(roles) == 0 {
+			s.writeError(w, http.StatusForbidden, "no_role_assigned", "API key must be assigned to a role or group")
+			return
+		}
+
+		entries, err := s.buildToolCatalog(r.Context(), tenantStore, roles)
+		if err != nil {
+			slog.Error("Failed to build tool catalog", "error", err)
+			s.writeError(w, http.StatusInternalServerError, "server_error", "Failed to list tools")
+			return
+		}
+		catalog.Data = entries
+	}
+
+	body, err := json.Marshal(catalog)
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, "server_error", "Failed to encode tools")
+		return
+	}
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	w.Header().Set("Vary", "Authorization")
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+// rolesForAPIKey returns the API key's direct role and the roles of its group.
+// Any role that can't be loaded is an error, so the catalog never lists tools
+// that a policy it couldn't read would block.
+func (s *Server) rolesForAPIKey(ctx context.Context, auth *AuthContext, tenantStore *postgres.TenantStore) ([]*domain.Role, error) {
+	if auth.APIKey == nil {
+		return nil, nil
+	}
+
+	var roles []*domain.Role
+	if auth.APIKey.RoleID != "" {
+		role, err := tenantStore.GetRole(ctx, auth.APIKey.RoleID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load role %s: %w", auth.APIKey.RoleID, err)
+		}
+		if role == nil {
+			return nil, fmt.Errorf("role %s not found", auth.APIKey.RoleID)
+		}
+		roles = append(roles, role)
+	}
+	if auth.APIKey.GroupID != "" {
+		groupRoles, err := tenantStore.GetGroupRoles(ctx, auth.APIKey.GroupID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load group roles for %s: %w", auth.APIKey.GroupID, err)
+		}
+		roles = append(roles, groupRoles...)
+	}
+	return roles, nil
+}
+
+// buildToolCatalog collects the tools the caller may use. available_tools are
+// listed when every role allows them, as chat requests check them; MCP tools
+// are listed when any role has ALLOW visibility, as in MCP tools/list.
+func (s *Server) buildToolCatalog(ctx context.Context, tenantStore *postgres.TenantStore, roles []*domain.Role) ([]ToolCatalogEntry, error) {
+	entries := []ToolCatalogEntry{}
+
+	// Gateway built-ins (tool_search is always listed by the MCP gateway)
+	if s.mcpGateway != nil {
+		entries = append(entries, ToolCatalogEntry{
+			Type:     "function",
+			Function: s.mcpGateway.GetToolSearchTool().Function,
+			Source:   ToolSourceBuiltIn,
+			Category: "search",
+		})
+	}
+
+	// available_tools, checked against each role's tool permissions
+	tools, err := tenantStore.ListTools(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, tool := range tools {
+		if tool.Enabled {
+			names = append(names, tool.Name)
+		}
+	}
+	roleTools := make(map[string]map[string]*domain.RoleTool, len(roles))
+	for _, role := range roles {
+		if roleTools[role.ID], err = listRoleToolsByName(ctx, tenantStore, role.ID); err != nil {
+			return nil, err
+		}
+	}
+	allowed, err := allowedCatalogTools(ctx, s.toolDiscoveryService, tenantStore, roles, roleTools, names)
+	if err != nil {
+		return nil, err
+	}
+	for _, tool := range tools {
+		if !tool.Enabled || !allowed[tool.Name] {
+			continue
+		}
+		source := ToolSourceCatalog
+		if tool.IsBuiltIn {
+			source = ToolSourceBuiltIn
+		}
+		entries = append(entries, ToolCatalogEntry{
+			Type: "function",
+			Function: domain.FunctionDefinition{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.Schema,
+			},
+			Source:   source,
+			Category: tool.Category,
+		})
+	}
+
+	// MCP tools with ALLOW visibility for at least one role
+	mcpTools, err := tenantStore.ListAllMCPTools(ctx)
+	if err != nil {
+		return nil, err
+	}
+	roleIDs := make([]string, len(roles))
+	for i, role := range roles {
+		roleIDs[i] = role.ID
+	}
+	perms, err := tenantStore.ListMCPToolVisibilities(ctx, roleIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, tool := range mcpTools {
+		if tool.IsDeprecated || perms[tool.ID] == nil || perms[tool.ID].Visibility != domain.MCPVisibilityAllow {
+			continue
+		}
+		entries = append(entries, ToolCatalogEntry{
+			Type: "function",
+			Function: domain.FunctionDefinition{
+				Name:        mcp.SanitizeToolName(tool.ServerName, tool.Name),
+				Description: tool.Description,
+				Parameters:  tool.InputSchema,
+			},
+			Source:    ToolSourceMCP,
+			Category:  tool.Category,
+			MCPServer: tool.ServerName,
+		})
+	}
+
+	// Stable order keeps the ETag stable
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Source != entries[j].Source {
+			return entries[i].Source < entries[j].Source
+		}
+		return entries[i].Function.Name < entries[j].Function.Name
+	})
+	return entries, nil
+}
+
+// roleToolsPageSize is how many role tools listRoleToolsByName reads at a time
+const roleToolsPageSize = 500
+
+// listRoleToolsByName returns the tools discovered for a role, by name
+func listRoleToolsByName(ctx context.Context, tenantStore *postgres.TenantStore, roleID string) (map[string]*domain.RoleTool, error) {
+	byName := make(map[string]*domain.RoleTool)
+	for offset := 0; ; offset += roleToolsPageSize {
+		page, total, err := tenantStore.ListRoleTools(ctx, roleID, domain.ToolFilter{}, roleToolsPageSize, offset)
+		if err != nil {
+			return nil, err
+		}
+		for _, tool := range page {
+			byName[tool.Name] = tool
+		}
+		if len(page) < roleToolsPageSize || offset+len(page) >= total {
+			return byName, nil
+		}
+	}
+}
+
+// allowedCatalogTools returns the named tools that every role allows. Each
+// role decides through CheckToolPermissions, as enforceToolPolicy does: a
+// tool the role hasn't discovered is pending, and a role with tool calling
+// disabled allows none.
+func allowedCatalogTools(ctx context.Context, svc *policy.ToolDiscoveryService, store policy.RoleToolStore, roles []*domain.Role, roleTools map[string]map[string]*domain.RoleTool, names []string) (map[string]bool, error) {
+	allowed := make(map[string]bool, len(names))
+	if len(roles) == 0 {
+		return allowed, nil
+	}
+	for _, name := range names {
+		allowed[name] = true
+	}
+
+	for _, role := range roles {
+		if role.Policy != nil && !role.Policy.ToolPolicies.AllowToolCalling {
+			return map[string]bool{}, nil
+		}
+
+		candidates := make([]*domain.RoleTool, 0, len(names))
+		for _, name := range names {
+			if tool, ok := roleTools[role.ID][name]; ok {
+				candidates = append(candidates, tool)
+			} else {
+				candidates = append(candidates, &domain.RoleTool{RoleID: role.ID, Name: name, Status: domain.ToolStatusPending})
+			}
+		}
+		result, err := svc.CheckToolPermissions(ctx, role.ID, candidates, roleToolPolicies(role), store)
+		if err != nil {
+			return nil, err
+		}
+		for _, check := range result.Tools {
+			if check.Status != "ALLOWED" {
+				delete(allowed, check.ToolName)
+			}
+		}
+	}
+	return allowed, nil
+}
@@ -0,0 +1,81 @@
+package http
+
+import (
+	"context"
+	"testing"
+
+	"modelgate/internal/domain"
+	"modelgate/internal/policy"
+)
+
+func TestAllowedCatalogTools(t *testing.T) {
+	allowCalling := &domain.RolePolicy{ToolPolicies: domain.ToolPolicies{AllowToolCalling: true, AllowedTools: []string{"*"}}}
+	roleTool := func(roleID, name string, status domain.ToolPermissionStatus) *domain.RoleTool {
+		return &domain.RoleTool{ID: roleID + "-" + name, RoleID: roleID, Name: name, Status: status}
+	}
+	names := []string{"search", "shell", "fetch", "deploy"}
+
+	tests := []struct {
+		name      string
+		roles     []*domain.Role
+		roleTools map[string]map[string]*domain.RoleTool
+		want      []string
+	}{
+		{
+			name:  "only allowed tools are listed; pending, denied and removed are not",
+			roles: []*domain.Role{{ID: "dev", Policy: allowCalling}},
+			roleTools: map[string]map[string]*domain.RoleTool{"dev": {
+				"search": roleTool("dev", "search", domain.ToolStatusAllowed),
+				"shell":  roleTool("dev", "shell", domain.ToolStatusDenied),
+				"fetch":  roleTool("dev", "fetch", domain.ToolStatusRemoved),
+			}},
+			want: []string{"search"},
+		},
+		{
+			name:  "every role must allow the tool",
+			roles: []*domain.Role{{ID: "dev", Policy: allowCalling}, {ID: "ops", Policy: allowCalling}},
+			roleTools: map[string]map[string]*domain.RoleTool{
+				"dev": {
+					"search": roleTool("dev", "search", domain.ToolStatusAllowed),
+					"deploy": roleTool("dev", "deploy", domain.ToolStatusAllowed),
+				},
+				"ops": {
+					"search": roleTool("ops", "search", domain.ToolStatusAllowed),
+					"deploy": roleTool("ops", "deploy", domain.ToolStatusPending),
+				},
+			},
+			want: []string{"search"},
+		},
+		{
+			name: "a role with tool calling disabled allows nothing",
+			roles: []*domain.Role{
+				{ID: "dev", Policy: allowCalling},
+				{ID: "ro", Policy: &domain.RolePolicy{ToolPolicies: domain.ToolPolicies{AllowToolCalling: false}}},
+			},
+			roleTools: map[string]map[string]*domain.RoleTool{
+				"dev": {"search": roleTool("dev", "search", domain.ToolStatusAllowed)},
+				"ro":  {"search": roleTool("ro", "search", domain.ToolStatusAllowed)},
+			},
+		},
+		{
+			name:  "no roles allow nothing",
+			roles: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allowed, err := allowedCatalogTools(context.Background(), policy.NewToolDiscoveryService(), nil, tt.roles, tt.roleTools, names)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(allowed) != len(tt.want) {
+				t.Fatalf("allowed = %v, want %v", allowed, tt.want)
+			}
+			for _, name := range tt.want {
+				if !allowed[name] {
+					t.Errorf("expected %s to be allowed, got %v", name, allowed)
+				}
+			}
+		})
+	}
+}
@@ -10,6 +10,7 @@ type ChatCompletionRequest struct {
 	Model            string        `json:"model"`
 	Messages         []ChatMessage `json:"messages"`
 	Temperature      *float32      `json:"temperature,omitempty"`
+	TopP             *float32      `json:"top_p,omitempty"`
 	MaxTokens        *int32        `json:"max_tokens,omitempty"`
 	Stream           bool          `json:"stream,omitempty"`
 	Tools            []Tool        `json:"tools,omitempty"`
@@ -21,6 +22,13 @@ type ChatCompletionRequest struct {
 	PresencePenalty  *float32      `json:"presence_penalty,omitempty"`
 	FrequencyPenalty *float32      `json:"frequency_penalty,omitempty"`
 	User             *string       `json:"user,omitempty"`
+	ThreadID         string        `json:"thread_id,omitempty"` // Continue a stored thread (see /v1/threads)
+
+	ParallelToolCalls *bool          `json:"parallel_tool_calls,omitempty"`
+	Documents         []ChatDocument `json:"documents,omitempty"` // Grounding documents, or references to stored documents (see /v1/documents)
+
+	thread    *threadTurn    // Set when ThreadID is used, to append the turn afterwards
+	retrieved []ChatDocument // Chunks retrieved for document references, echoed in the response
 }
 
 // ChatMessage represents a message in the conversation
@@ -31,6 +39,26 @@ type ChatMessage struct {
 	ToolCalls        []ToolCall  `json:"tool_calls,omitempty"`
 	ToolCallID       string      `json:"tool_call_id,omitempty"`
 	ReasoningContent *string     `json:"reasoning_content,omitempty"`
+	Citations        []Citation  `json:"citations,omitempty"`
+}
+
+// ChatDocument is a document the model can ground its answer in. Without
+// text it references stored documents: the one with its ID, or those in its
+// collection.
+type ChatDocument struct {
+	ID         string            `json:"id,omitempty"`
+	Text       string            `json:"text,omitempty"`
+	Fields     map[string]string `json:"fields,omitempty"` // Extra fields such as title or url
+	Collection string            `json:"collection,omitempty"`
+}
+
+// Citation ties a span of the message content to the documents or tool
+// calls supporting it
+type Citation struct {
+	Start   int      `json:"start"`
+	End     int      `json:"end"`
+	Text    string   `json:"text"`
+	Sources []string `json:"sources"`
 }
 
 // Tool represents a tool definition
@@ -68,6 +96,8 @@ type ChatCompletionResponse struct {
 	Choices           []Choice `json:"choices"`
 	Usage             *Usage   `json:"usage,omitempty"`
 	SystemFingerprint *string  `json:"system_fingerprint,omitempty"`
+
+	Documents []ChatDocument `json:"documents,omitempty"` // Chunks retrieved for document references, which citations refer to
 }
 
 // Choice represents a completion choice
@@ -93,6 +123,8 @@ type ChatCompletionChunk struct {
 	Model             string        `json:"model"`
 	Choices           []ChunkChoice `json:"choices"`
 	SystemFingerprint *string       `json:"system_fingerprint,omitempty"`
+
+	Documents []ChatDocument `json:"documents,omitempty"` // On the first chunk: chunks retrieved for document references
 }
 
 // ChunkChoice represents a streaming chunk choice
@@ -104,9 +136,26 @@ type ChunkChoice struct {
 
 // Delta represents the delta in a streaming chunk
 type Delta struct {
-	Role      *string    `json:"role,omitempty"`
-	Content   *string    `json:"content,omitempty"`
-	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	Role      *string         `json:"role,omitempty"`
+	Content   *string         `json:"content,omitempty"`
+	ToolCalls []ToolCallChunk `json:"tool_calls,omitempty"`
+	Citations []Citation      `json:"citations,omitempty"`
+}
+
+// ToolCallChunk is a fragment of a streamed tool call. The first fragment of
+// a call has its ID, type and function name; the rest only add arguments to
+// the call at Index.
+type ToolCallChunk struct {
+	Index    int                `json:"index"`
+	ID       string             `json:"id,omitempty"`
+	Type     string             `json:"type,omitempty"`
+	Function *FunctionCallChunk `json:"function,omitempty"`
+}
+
+// FunctionCallChunk is a fragment of a streamed function call
+type FunctionCallChunk struct {
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments"`
 }
 
 // =============================================================================
@@ -161,6 +210,24 @@ type ModelData struct {
 	OwnedBy string `json:"owned_by"`
 }
 
+// ModelDetail is a model's full catalog entry, from GET /v1/models/{model}/details
+type ModelDetail struct {
+	ModelData
+	Name            string       `json:"name"`
+	Provider        string       `json:"provider"`
+	Capabilities    []string     `json:"capabilities"` // tools, vision, reasoning
+	ContextWindow   uint32       `json:"context_window"`
+	MaxOutputTokens uint32       `json:"max_output_tokens"`
+	Pricing         ModelPricing `json:"pricing"`
+}
+
+// ModelPricing is a model's price in USD per 1M tokens; zero when unknown
+type ModelPricing struct {
+	InputPer1M       float64 `json:"input_per_1m"`
+	OutputPer1M      float64 `json:"output_per_1m"`
+	CachedInputPer1M float64 `json:"cached_input_per_1m,omitempty"`
+}
+
 // =============================================================================
 // Error Types
 // =============================================================================
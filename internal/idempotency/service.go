@@ -0,0 +1,138 @@
+// Package idempotency makes retried requests safe. A request sent with an
+// Idempotency-Key claims the key for its API key; once it succeeds its
+// response is stored, and requests repeating the key get that response back
+// instead of reaching the provider again.
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+)
+
+// Errors returned by Begin. HTTP handlers map these to status codes.
+var (
+	ErrInProgress = errors.New("a request with this idempotency key is still in progress")
+	ErrKeyReused  = errors.New("this idempotency key was already used for a different request")
+)
+
+// MaxKeyLength is the longest Idempotency-Key accepted
+const MaxKeyLength = 255
+
+// Store is the persistence used by the service (implemented by postgres.TenantStore)
+type Store interface {
+	ClaimIdempotencyKey(ctx context.Context, rec *domain.IdempotencyRecord, now time.Time) (*domain.IdempotencyRecord, error)
+	CompleteIdempotencyKey(ctx context.Context, rec *domain.IdempotencyRecord) error
+	ReleaseIdempotencyKey(ctx context.Context, apiKeyID, key, requestHash string) error
+	DeleteExpiredIdempotencyKeys(ctx context.Context, now time.Time) (int64, error)
+}
+
+// Service claims, completes and replays idempotency keys
+type Service struct {
+	config atomic.Pointer[config.IdempotencyConfig]
+	store  Store
+}
+
+// NewService creates a new idempotency service
+func NewService(cfg config.IdempotencyConfig, store Store) *Service {
+	s := &Service{store: store}
+	s.SetConfig(cfg)
+	return s
+}
+
+// SetConfig applies reloaded settings
+func (s *Service) SetConfig(cfg config.IdempotencyConfig) {
+	s.config.Store(&cfg)
+}
+
+// Config returns the current settings
+func (s *Service) Config() config.IdempotencyConfig {
+	return *s.config.Load()
+}
+
+// Begin claims key for a request whose endpoint and body hash to
+// requestHash. It returns nil when the request should run, after which
+// Complete or Release must be called. It returns the stored record when the
+// key already completed the same request, ErrInProgress while that request
+// runs, and ErrKeyReused when the key was used for another request.
+func (s *Service) Begin(ctx context.Context, apiKeyID, key, requestHash string) (*domain.IdempotencyRecord, error) {
+	if key == "" || len(key) > MaxKeyLength {
+		return nil, fmt.Errorf("idempotency key must be 1 to %d characters", MaxKeyLength)
+	}
+	cfg := s.Config()
+	now := time.Now()
+	existing, err := s.store.ClaimIdempotencyKey(ctx, &domain.IdempotencyRecord{
+		APIKeyID:    apiKeyID,
+		Key:         key,
+		RequestHash: requestHash,
+		Status:      domain.IdempotencyInProgress,
+		LockedUntil: now.Add(cfg.LockTimeout),
+		ExpiresAt:   now.Add(cfg.TTL),
+	}, now)
+	if err != nil {
+		return nil, fmt.Errorf("claim idempotency key: %w", err)
+	}
+	switch {
+	case existing == nil:
+		return nil, nil
+	case existing.RequestHash != requestHash:
+		return nil, ErrKeyReused
+	case existing.Status != domain.IdempotencyCompleted:
+		return nil, ErrInProgress
+	}
+	return existing, nil
+}
+
+// Complete stores the response of a request that claimed its key
+func (s *Service) Complete(ctx context.Context, rec *domain.IdempotencyRecord) {
+	rec.Status = domain.IdempotencyCompleted
+	rec.ExpiresAt = time.Now().Add(s.Config().TTL)
+	if err := s.store.CompleteIdempotencyKey(ctx, rec); err != nil {
+		slog.Error("Failed to store idempotent response", "api_key_id", rec.APIKeyID, "error", err)
+	}
+}
+
+// Release gives up a claimed key without storing a response, so the request
+// can be retried
+func (s *Service) Release(ctx context.Context, apiKeyID, key, requestHash string) {
+	if err := s.store.ReleaseIdempotencyKey(ctx, apiKeyID, key, requestHash); err != nil {
+		slog.Error("Failed to release idempotency key", "api_key_id", apiKeyID, "error", err)
+	}
+}
+
+// CleanupExpired deletes expired keys
+func (s *Service) CleanupExpired(ctx context.Context) (int64, error) {
+	return s.store.DeleteExpiredIdempotencyKeys(ctx, time.Now())
+}
+
+// StartJanitor periodically deletes expired keys until ctx is cancelled
+func (s *Service) StartJanitor(ctx context.Context) {
+	interval := s.Config().CleanupInterval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				n, err := s.CleanupExpired(ctx)
+				if err != nil {
+					slog.Error("Failed to clean up expired idempotency keys", "error", err)
+				} else if n > 0 {
+					slog.Info("Cleaned up expired idempotency keys", "count", n)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,109 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+)
+
+// memStore is an in-memory Store for tests, following the postgres claim rules
+type memStore struct {
+	records map[string]domain.IdempotencyRecord
+}
+
+func newMemStore() *memStore {
+	return &memStore{records: map[string]domain.IdempotencyRecord{}}
+}
+
+func (m *memStore) ClaimIdempotencyKey(_ context.Context, rec *domain.IdempotencyRecord, now time.Time) (*domain.IdempotencyRecord, error) {
+	id := rec.APIKeyID + "/" + rec.Key
+	if existing, ok := m.records[id]; ok {
+		expired := existing.ExpiresAt.Before(now)
+		stale := existing.Status == domain.IdempotencyInProgress && existing.LockedUntil.Before(now)
+		if !expired && !stale {
+			return &existing, nil
+		}
+	}
+	m.records[id] = *rec
+	return nil, nil
+}
+
+func (m *memStore) CompleteIdempotencyKey(_ context.Context, rec *domain.IdempotencyRecord) error {
+	id := rec.APIKeyID + "/" + rec.Key
+	if existing, ok := m.records[id]; ok && existing.RequestHash == rec.RequestHash {
+		m.records[id] = *rec
+	}
+	return nil
+}
+
+func (m *memStore) ReleaseIdempotencyKey(_ context.Context, apiKeyID, key, requestHash string) error {
+	id := apiKeyID + "/" + key
+	if existing, ok := m.records[id]; ok && existing.RequestHash == requestHash && existing.Status == domain.IdempotencyInProgress {
+		delete(m.records, id)
+	}
+	return nil
+}
+
+func (m *memStore) DeleteExpiredIdempotencyKeys(_ context.Context, now time.Time) (int64, error) {
+	var n int64
+	for id, rec := range m.records {
+		if rec.ExpiresAt.Before(now) {
+			delete(m.records, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func TestIdempotencyKeys(t *testing.T) {
+	ctx := context.Background()
+	store := newMemStore()
+	svc := NewService(config.IdempotencyConfig{Enabled: true, TTL: time.Hour, LockTimeout: time.Minute}, store)
+
+	// The first request claims the key; a concurrent duplicate is turned away
+	if rec, err := svc.Begin(ctx, "key-1", "retry-1", "hash-a"); rec != nil || err != nil {
+		t.Fatalf("expected claim, got %v, %v", rec, err)
+	}
+	if _, err := svc.Begin(ctx, "key-1", "retry-1", "hash-a"); !errors.Is(err, ErrInProgress) {
+		t.Fatalf("expected ErrInProgress, got %v", err)
+	}
+
+	// Keys are scoped to the API key
+	if rec, err := svc.Begin(ctx, "key-2", "retry-1", "hash-a"); rec != nil || err != nil {
+		t.Fatalf("expected another API key to claim its own key, got %v, %v", rec, err)
+	}
+
+	// Once completed the response is replayed, but not for a different body
+	svc.Complete(ctx, &domain.IdempotencyRecord{APIKeyID: "key-1", Key: "retry-1", RequestHash: "hash-a", StatusCode: 200, Body: []byte(`{"id":"chatcmpl-1"}`)})
+	rec, err := svc.Begin(ctx, "key-1", "retry-1", "hash-a")
+	if err != nil || rec == nil || rec.StatusCode != 200 || string(rec.Body) != `{"id":"chatcmpl-1"}` {
+		t.Fatalf("expected stored response, got %+v, %v", rec, err)
+	}
+	if _, err := svc.Begin(ctx, "key-1", "retry-1", "hash-b"); !errors.Is(err, ErrKeyReused) {
+		t.Fatalf("expected ErrKeyReused, got %v", err)
+	}
+
+	// Releasing a failed request lets it be retried
+	svc.Release(ctx, "key-2", "retry-1", "hash-a")
+	if rec, err := svc.Begin(ctx, "key-2", "retry-1", "hash-a"); rec != nil || err != nil {
+		t.Fatalf("expected released key to be claimable, got %v, %v", rec, err)
+	}
+
+	// A request that died holding its key loses it after the lock timeout
+	store.records["key-2/retry-1"] = domain.IdempotencyRecord{
+		APIKeyID: "key-2", Key: "retry-1", RequestHash: "hash-a", Status: domain.IdempotencyInProgress,
+		LockedUntil: time.Now().Add(-time.Second), ExpiresAt: time.Now().Add(time.Hour),
+	}
+	if rec, err := svc.Begin(ctx, "key-2", "retry-1", "hash-a"); rec != nil || err != nil {
+		t.Fatalf("expected stale lock to be taken over, got %v, %v", rec, err)
+	}
+
+	if _, err := svc.Begin(ctx, "key-1", strings.Repeat("k", MaxKeyLength+1), "hash-a"); err == nil {
+		t.Fatal("expected an over-long key to be rejected")
+	}
+}
@@ -0,0 +1,337 @@
+// Package injection detects prompt injection and jailbreak attempts by their
+// embedding similarity to a corpus of known attacks. The corpus is stored
+// with pgvector: the builtin patterns are seeded at start and admins add
+// their own. Prompts are embedded with the injection_detection embedder and
+// compared with the nearest patterns; each role decides, in its ML detection
+// settings, how similar a prompt must be to count and whether to block or
+// flag it.
+package injection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"modelgate/internal/domain"
+)
+
+// Store is the persistence used by the service (implemented by postgres.TenantStore)
+type Store interface {
+	ListInjectionPatterns(ctx context.Context) ([]*domain.InjectionPattern, error)
+	CreateInjectionPattern(ctx context.Context, p *domain.InjectionPattern) (bool, error)
+	DeleteInjectionPattern(ctx context.Context, id string) (*domain.InjectionPattern, error)
+	SetInjectionPatternEmbedding(ctx context.Context, id string, embedding []float32) error
+	NearestInjectionPattern(ctx context.Context, embedding []float32) (*domain.InjectionMatch, error)
+	CreateAuditLog(ctx context.Context, log *domain.AuditLog) error
+}
+
+// Embedder embeds texts
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// ErrPatternNotFound is returned when deleting an unknown pattern
+var ErrPatternNotFound = errors.New("injection pattern not found")
+
+const (
+	// segmentChars is the longest piece of a prompt embedded on its own. An
+	// attack hidden in a long document would be diluted in an embedding of
+	// the whole prompt, so long prompts are compared piece by piece.
+	segmentChars = 300
+
+	// maxSegments bounds the embeddings per prompt; longer prompts are
+	// checked on their first and last pieces
+	maxSegments = 16
+
+	// embedBatch is how many patterns are embedded per call when indexing
+	embedBatch = 32
+
+	// maxPatternChars bounds custom patterns, which should be a typical phrasing
+	maxPatternChars = 2000
+)
+
+// Actor is who changed the corpus, for the audit log
+type Actor struct {
+	ID        string
+	Email     string
+	IPAddress string
+	UserAgent string
+}
+
+// Service matches prompts against the corpus
+type Service struct {
+	store    Store
+	embedder Embedder
+
+	indexMu sync.Mutex // One indexing pass at a time
+}
+
+// NewService creates a new injection detection service
+func NewService(store Store, embedder Embedder) *Service {
+	return &Service{store: store, embedder: embedder}
+}
+
+// Seed adds the builtin patterns missing from the store and embeds every
+// pattern without a vector
+func (s *Service) Seed(ctx context.Context) error {
+	added := 0
+	for _, b := range builtinCorpus {
+		ok, err := s.store.CreateInjectionPattern(ctx, &domain.InjectionPattern{
+			Text:      b.text,
+			Category:  b.category,
+			Builtin:   true,
+			CreatedAt: time.Now(),
+		})
+		if err != nil {
+			return fmt.Errorf("seed injection corpus: %w", err)
+		}
+		if ok {
+			added++
+		}
+	}
+	if added > 0 {
+		slog.Info("Seeded injection detection corpus", "patterns", added)
+	}
+	_, err := s.Reindex(ctx)
+	return err
+}
+
+// Reindex embeds the patterns without a vector, after seeding or when the
+// embedder changed, and returns how many it embedded
+func (s *Service) Reindex(ctx context.Context) (int, error) {
+	s.indexMu.Lock()
+	defer s.indexMu.Unlock()
+
+	patterns, err := s.store.ListInjectionPatterns(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("list injection patterns: %w", err)
+	}
+	var pending []*domain.InjectionPattern
+	for _, p := range patterns {
+		if !p.Embedded {
+			pending = append(pending, p)
+		}
+	}
+
+	indexed := 0
+	for start := 0; start < len(pending); start += embedBatch {
+		batch := pending[start:min(start+embedBatch, len(pending))]
+		texts := make([]string, len(batch))
+		for i, p := range batch {
+			texts[i] = p.Text
+		}
+		vectors, err := s.embedder.Embed(ctx, texts)
+		if err != nil {
+			return indexed, fmt.Errorf("embed injection patterns: %w", err)
+		}
+		if len(vectors) != len(batch) {
+			return indexed, fmt.Errorf("embedder returned %d vectors for %d patterns", len(vectors), len(batch))
+		}
+		for i, p := range batch {
+			if err := s.store.SetInjectionPatternEmbedding(ctx, p.ID, vectors[i]); err != nil {
+				return indexed, fmt.Errorf("store injection pattern embedding: %w", err)
+			}
+			indexed++
+		}
+	}
+	return indexed, nil
+}
+
+// Match returns the corpus pattern most similar to a prompt, or nil when
+// the corpus has no embedded pattern (implements policy.InjectionMatcher)
+func (s *Service) Match(ctx context.Context, text string) (*domain.InjectionMatch, error) {
+	segments := splitSegments(text)
+	if len(segments) == 0 {
+		return nil, nil
+	}
+	vectors, err := s.embedder.Embed(ctx, segments)
+	if err != nil {
+		return nil, fmt.Errorf("embed prompt: %w", err)
+	}
+
+	var best *domain.InjectionMatch
+	for _, vec := range vectors {
+		match, err := s.store.NearestInjectionPattern(ctx, vec)
+		if err != nil {
+			return nil, err
+		}
+		if match != nil && (best == nil || match.Similarity > best.Similarity) {
+			best = match
+		}
+	}
+	return best, nil
+}
+
+// List returns the corpus
+func (s *Service) List(ctx context.Context) ([]*domain.InjectionPattern, error) {
+	return s.store.ListInjectionPatterns(ctx)
+}
+
+// Add extends the corpus with a custom pattern and embeds it. If the embedder
+// is unavailable the pattern is kept and embedded by the next reindex.
+func (s *Service) Add(ctx context.Context, text string, category domain.InjectionCategory, actor Actor) (*domain.InjectionPattern, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, fmt.Errorf("pattern text is required")
+	}
+	if len(text) > maxPatternChars {
+		return nil, fmt.Errorf("pattern text is longer than %d characters", maxPatternChars)
+	}
+	if category == "" {
+		category = domain.InjectionCategoryInjection
+	}
+	if !category.Valid() {
+		return nil, fmt.Errorf("unknown injection category %q", category)
+	}
+
+	p := &domain.InjectionPattern{
+		Text:      text,
+		Category:  category,
+		CreatedBy: actor.Email,
+		CreatedAt: time.Now(),
+	}
+	added, err := s.store.CreateInjectionPattern(ctx, p)
+	if err != nil {
+		return nil, fmt.Errorf("save injection pattern: %w", err)
+	}
+	if !added {
+		return nil, fmt.Errorf("the corpus already has this pattern")
+	}
+
+	if vectors, err := s.embedder.Embed(ctx, []string{text}); err != nil || len(vectors) != 1 {
+		slog.Warn("Failed to embed injection pattern, it will be embedded on the next reindex", "id", p.ID, "error", err)
+	} else if err := s.store.SetInjectionPatternEmbedding(ctx, p.ID, vectors[0]); err != nil {
+		slog.Warn("Failed to store injection pattern embedding", "id", p.ID, "error", err)
+	} else {
+		p.Embedded = true
+	}
+
+	s.audit(ctx, domain.AuditActionCreate, p, actor)
+	return p, nil
+}
+
+// Delete removes a custom pattern. Builtin patterns are part of the curated
+// corpus and can't be removed.
+func (s *Service) Delete(ctx context.Context, id string, actor Actor) (*domain.InjectionPattern, error) {
+	patterns, err := s.store.ListInjectionPatterns(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range patterns {
+		if p.ID == id && p.Builtin {
+			return nil, fmt.Errorf("builtin injection patterns can't be deleted")
+		}
+	}
+
+	p, err := s.store.DeleteInjectionPattern(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, ErrPatternNotFound
+	}
+	s.audit(ctx, domain.AuditActionDelete, p, actor)
+	return p, nil
+}
+
+func (s *Service) audit(ctx context.Context, action domain.AuditAction, p *domain.InjectionPattern, actor Actor) {
+	entry := &domain.AuditLog{
+		Action:       action,
+		ResourceType: domain.AuditResourceInjectionPattern,
+		ResourceID:   p.ID,
+		ResourceName: string(p.Category),
+		ActorID:      actor.ID,
+		ActorEmail:   actor.Email,
+		ActorType:    "admin",
+		IPAddress:    actor.IPAddress,
+		UserAgent:    actor.UserAgent,
+		NewValue:     map[string]any{"text": p.Text, "category": p.Category},
+		Status:       "success",
+	}
+	if err := s.store.CreateAuditLog(context.WithoutCancel(ctx), entry); err != nil {
+		slog.Error("Failed to audit injection pattern change", "id", p.ID, "error", err)
+	}
+}
+
+// splitSegments cuts a prompt into pieces of at most segmentChars, breaking
+// after sentences and lines where possible. Past maxSegments pieces, the
+// first and last halves are kept.
+func splitSegments(text string) []string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+	if len(text) <= segmentChars {
+		return []string{text}
+	}
+
+	var segments []string
+	var current strings.Builder
+	flush := func() {
+		if s := strings.TrimSpace(current.String()); s != "" {
+			segments = append(segments, s)
+		}
+		current.Reset()
+	}
+	for _, sentence := range splitSentences(text) {
+		if current.Len() > 0 && current.Len()+len(sentence) > segmentChars {
+			flush()
+		}
+		for len(sentence) > segmentChars {
+			cut := segmentChars
+			for cut > 0 && !isBreak(sentence[cut-1]) {
+				cut--
+			}
+			if cut == 0 {
+				cut = segmentChars
+				for cut > 0 && sentence[cut]&0xC0 == 0x80 { // Don't split a UTF-8 sequence
+					cut--
+				}
+			}
+			current.WriteString(sentence[:cut])
+			flush()
+			sentence = sentence[cut:]
+		}
+		current.WriteString(sentence)
+	}
+	flush()
+
+	if len(segments) > maxSegments {
+		half := maxSegments / 2
+		segments = append(segments[:half], segments[len(segments)-half:]...)
+	}
+	return segments
+}
+
+// splitSentences splits text after sentence ends and line breaks, keeping
+// the separators
+func splitSentences(text string) []string {
+	var sentences []string
+	start := 0
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '\n':
+		case '.', '!', '?':
+			if i+1 < len(text) && !unicode.IsSpace(rune(text[i+1])) {
+				continue
+			}
+		default:
+			continue
+		}
+		sentences = append(sentences, text[start:i+1])
+		start = i + 1
+	}
+	if start < len(text) {
+		sentences = append(sentences, text[start:])
+	}
+	return sentences
+}
+
+func isBreak(b byte) bool {
+	return b == ' ' || b == '\t' || b == ',' || b == ';'
+}
@@ -0,0 +1,280 @@
+// Package keybudget enforces the spending caps of individual API keys. Spend
+// is loaded from usage records and kept current between loads by adding the
+// cost of each request as it is recorded, so the remaining budget is
+// reported in real time.
+package keybudget
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+// spendReloadInterval is how often a key's spend is reloaded from usage
+// records, correcting any drift in the running total
+const spendReloadInterval = time.Minute
+
+// ErrInvalidBudget is returned when a budget fails validation
+var ErrInvalidBudget = errors.New("invalid budget")
+
+// Store is the persistence used by the service (implemented by postgres.TenantStore)
+type Store interface {
+	UpdateAPIKeyBudget(ctx context.Context, id string, budget *domain.APIKeyBudget) error
+	GetAPIKeySpend(ctx context.Context, keyID string, dayStart, monthStart time.Time) (*domain.APIKeySpend, error)
+}
+
+// Check is the outcome of checking a request against its API key's budget
+type Check struct {
+	Allowed bool
+	Warning string // Set when a soft limit was reached but the request is allowed
+	Reason  string // Set when the request is blocked
+	Status  *domain.APIKeyBudgetStatus
+}
+
+// Alert is the payload posted to a budget's alert webhook
+type Alert struct {
+	Type       string    `json:"type"`   // "api_key_budget_soft_limit" or "api_key_budget_hard_limit"
+	Window     string    `json:"window"` // "daily" or "monthly"
+	APIKeyID   string    `json:"api_key_id"`
+	APIKeyName string    `json:"api_key_name"`
+	LimitUSD   float64   `json:"limit_usd"`
+	SpentUSD   float64   `json:"spent_usd"`
+	At         time.Time `json:"at"`
+}
+
+type keySpend struct {
+	spend                domain.APIKeySpend
+	dayStart, monthStart time.Time
+	loadedAt             time.Time
+}
+
+// Service checks requests against API key budgets
+type Service struct {
+	store      Store
+	httpClient *http.Client
+	now        func() time.Time
+
+	mu      sync.Mutex
+	spend   map[string]*keySpend // By API key ID
+	alerted map[string]bool      // key ID, window, alert type and window start
+}
+
+// NewService creates a new API key budget service
+func NewService(store Store) *Service {
+	return &Service{
+		store:      store,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		now:        time.Now,
+		spend:      make(map[string]*keySpend),
+		alerted:    make(map[string]bool),
+	}
+}
+
+// budgetWindows returns the start of the UTC day and month containing t
+func budgetWindows(t time.Time) (day, month time.Time) {
+	t = t.UTC()
+	day = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	month = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return day, month
+}
+
+// Validate checks a budget's limits
+func Validate(b *domain.APIKeyBudget) error {
+	windows := []struct {
+		name       string
+		soft, hard float64
+	}{
+		{"daily", b.DailySoftLimitUSD, b.DailyHardLimitUSD},
+		{"monthly", b.MonthlySoftLimitUSD, b.MonthlyHardLimitUSD},
+	}
+	for _, w := range windows {
+		if w.soft < 0 || w.hard < 0 {
+			return fmt.Errorf("%w: %s limits cannot be negative", ErrInvalidBudget, w.name)
+		}
+		if w.soft > 0 && w.hard > 0 && w.soft > w.hard {
+			return fmt.Errorf("%w: %s soft limit is above the hard limit", ErrInvalidBudget, w.name)
+		}
+	}
+	return nil
+}
+
+// SetBudget saves an API key's budget; nil or a budget without limits removes it
+func (s *Service) SetBudget(ctx context.Context, keyID string, budget *domain.APIKeyBudget) error {
+	if budget != nil && budget.IsZero() {
+		budget = nil
+	}
+	if budget != nil {
+		if err := Validate(budget); err != nil {
+			return err
+		}
+	}
+	if err := s.store.UpdateAPIKeyBudget(ctx, keyID, budget); err != nil {
+		return fmt.Errorf("update API key budget: %w", err)
+	}
+	return nil
+}
+
+// Status returns an API key's spend and what is left of its budget
+func (s *Service) Status(ctx context.Context, key *domain.APIKey) (*domain.APIKeyBudgetStatus, error) {
+	spend, err := s.currentSpend(ctx, key.ID)
+	if err != nil {
+		return nil, err
+	}
+	return status(key.Budget, spend), nil
+}
+
+// Check checks whether a request made with the key may proceed. Crossing a
+// soft or hard limit calls the budget's alert webhook once per window.
+func (s *Service) Check(ctx context.Context, key *domain.APIKey) (*Check, error) {
+	if key.Budget == nil || key.Budget.IsZero() {
+		return &Check{Allowed: true}, nil
+	}
+	ks, err := s.currentSpend(ctx, key.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	b := key.Budget
+	check := &Check{Allowed: true, Status: status(b, ks)}
+	windows := []struct {
+		name        string
+		soft, hard  float64
+		spent       float64
+		windowStart time.Time
+	}{
+		{"daily", b.DailySoftLimitUSD, b.DailyHardLimitUSD, ks.spend.DailyUSD, ks.dayStart},
+		{"monthly", b.MonthlySoftLimitUSD, b.MonthlyHardLimitUSD, ks.spend.MonthlyUSD, ks.monthStart},
+	}
+	for _, w := range windows {
+		switch {
+		case w.hard > 0 && w.spent >= w.hard:
+			s.alert(ctx, key, "api_key_budget_hard_limit", w.name, w.hard, w.spent, w.windowStart)
+			check.Allowed = false
+			check.Reason = fmt.Sprintf("API key %q exceeded its %s budget of $%.2f", key.Name, w.name, w.hard)
+			return check, nil
+		case w.soft > 0 && w.spent >= w.soft:
+			s.alert(ctx, key, "api_key_budget_soft_limit", w.name, w.soft, w.spent, w.windowStart)
+			if check.Warning == "" {
+				check.Warning = fmt.Sprintf("API key %s soft limit of $%.2f reached ($%.2f spent)", w.name, w.soft, w.spent)
+			}
+		}
+	}
+	return check, nil
+}
+
+// Record adds the cost of a finished request to its key's running total
+func (s *Service) Record(keyID string, costUSD float64) {
+	if keyID == "" || costUSD <= 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ks, ok := s.spend[keyID]; ok {
+		ks.spend.DailyUSD += costUSD
+		ks.spend.MonthlyUSD += costUSD
+	}
+}
+
+// currentSpend returns a key's spend in the current windows, reloading it
+// when a window rolled over or the reload interval passed
+func (s *Service) currentSpend(ctx context.Context, keyID string) (*keySpend, error) {
+	now := s.now()
+	day, month := budgetWindows(now)
+	s.mu.Lock()
+	ks, ok := s.spend[keyID]
+	if ok && ks.dayStart.Equal(day) && now.Sub(ks.loadedAt) < spendReloadInterval {
+		copied := *ks
+		s.mu.Unlock()
+		return &copied, nil
+	}
+	s.mu.Unlock()
+
+	spend, err := s.store.GetAPIKeySpend(ctx, keyID, day, month)
+	if err != nil {
+		return nil, fmt.Errorf("get API key spend: %w", err)
+	}
+	ks = &keySpend{spend: *spend, dayStart: day, monthStart: month, loadedAt: now}
+	s.mu.Lock()
+	s.spend[keyID] = ks
+	copied := *ks
+	s.mu.Unlock()
+	return &copied, nil
+}
+
+func status(b *domain.APIKeyBudget, ks *keySpend) *domain.APIKeyBudgetStatus {
+	st := &domain.APIKeyBudgetStatus{Spend: ks.spend}
+	if b == nil {
+		return st
+	}
+	remaining := func(limit, spent float64) *float64 {
+		if limit <= 0 {
+			return nil
+		}
+		left := max(limit-spent, 0)
+		return &left
+	}
+	st.DailyRemainingUSD = remaining(b.DailyHardLimitUSD, ks.spend.DailyUSD)
+	st.MonthlyRemainingUSD = remaining(b.MonthlyHardLimitUSD, ks.spend.MonthlyUSD)
+	return st
+}
+
+// alert posts a limit event to the budget's webhook, once per key, window and
+// limit in each window. Failures are logged; the alert is not retried.
+func (s *Service) alert(ctx context.Context, key *domain.APIKey, alertType, window string, limit, spent float64, windowStart time.Time) {
+	if key.Budget.AlertWebhook == "" {
+		return
+	}
+	dedupe := fmt.Sprintf("%s:%s:%s:%d", key.ID, window, alertType, windowStart.Unix())
+	s.mu.Lock()
+	if s.alerted[dedupe] {
+		s.mu.Unlock()
+		return
+	}
+	s.alerted[dedupe] = true
+	s.mu.Unlock()
+
+	payload := Alert{
+		Type:       alertType,
+		Window:     window,
+		APIKeyID:   key.ID,
+		APIKeyName: key.Name,
+		LimitUSD:   limit,
+		SpentUSD:   spent,
+		At:         s.now(),
+	}
+	url := key.Budget.AlertWebhook
+	go func() {
+		if err := s.post(context.WithoutCancel(ctx), url, payload); err != nil {
+			slog.Error("Failed to send API key budget alert", "api_key_id", payload.APIKeyID, "type", alertType, "error", err)
+		}
+	}()
+}
+
+func (s *Service) post(ctx context.Context, url string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package keybudget
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+type memStore struct {
+	spend domain.APIKeySpend
+	loads int
+}
+
+func (m *memStore) UpdateAPIKeyBudget(ctx context.Context, id string, budget *domain.APIKeyBudget) error {
+	return nil
+}
+
+func (m *memStore) GetAPIKeySpend(ctx context.Context, keyID string, dayStart, monthStart time.Time) (*domain.APIKeySpend, error) {
+	m.loads++
+	spend := m.spend
+	return &spend, nil
+}
+
+func TestCheckBudget(t *testing.T) {
+	alerts := make(chan Alert, 4)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var a Alert
+		json.NewDecoder(r.Body).Decode(&a)
+		alerts <- a
+	}))
+	defer webhook.Close()
+
+	store := &memStore{spend: domain.APIKeySpend{DailyUSD: 4, MonthlyUSD: 40}}
+	svc := NewService(store)
+	key := &domain.APIKey{ID: "k1", Name: "ci", Budget: &domain.APIKeyBudget{
+		DailySoftLimitUSD: 5, DailyHardLimitUSD: 10, MonthlyHardLimitUSD: 100, AlertWebhook: webhook.URL,
+	}}
+	ctx := context.Background()
+
+	// Under the soft limit: allowed, with the remaining budget reported
+	check, err := svc.Check(ctx, key)
+	if err != nil || !check.Allowed || check.Warning != "" {
+		t.Fatalf("expected the request to be allowed without warning, got %+v, %v", check, err)
+	}
+	if *check.Status.DailyRemainingUSD != 6 || *check.Status.MonthlyRemainingUSD != 60 {
+		t.Fatalf("expected $6 and $60 remaining, got %+v", check.Status)
+	}
+
+	// Recorded costs count at once, without reloading the spend
+	svc.Record("k1", 1.5)
+	check, _ = svc.Check(ctx, key)
+	if !check.Allowed || check.Warning == "" || *check.Status.DailyRemainingUSD != 4.5 {
+		t.Fatalf("expected a soft limit warning with $4.5 left, got %+v", check)
+	}
+	if a := <-alerts; a.Type != "api_key_budget_soft_limit" || a.Window != "daily" || a.SpentUSD != 5.5 {
+		t.Fatalf("unexpected soft limit alert %+v", a)
+	}
+
+	// Past the hard limit the request is refused
+	svc.Record("k1", 5)
+	check, _ = svc.Check(ctx, key)
+	if check.Allowed || check.Reason == "" {
+		t.Fatalf("expected the request to be blocked, got %+v", check)
+	}
+	if a := <-alerts; a.Type != "api_key_budget_hard_limit" {
+		t.Fatalf("unexpected hard limit alert %+v", a)
+	}
+	if store.loads != 1 {
+		t.Fatalf("expected the spend to be loaded once, got %d", store.loads)
+	}
+
+	// Each limit alerts once per window
+	svc.Check(ctx, key)
+	select {
+	case a := <-alerts:
+		t.Fatalf("unexpected repeated alert %+v", a)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if err := Validate(&domain.APIKeyBudget{DailySoftLimitUSD: 10, DailyHardLimitUSD: 5}); err == nil {
+		t.Fatal("expected a soft limit above the hard limit to be rejected")
+	}
+}
@@ -0,0 +1,234 @@
+// Package killswitch stops traffic to a provider or a single model at runtime
+// during incidents. A switch applies at once on the instance that set it and
+// within refreshInterval on the others, and can re-enable itself after a set
+// time. Every change is recorded in the audit log.
+package killswitch
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+// Store is the persistence used by the service (implemented by postgres.TenantStore)
+type Store interface {
+	ListKillSwitches(ctx context.Context) ([]*domain.KillSwitch, error)
+	SaveKillSwitch(ctx context.Context, ks *domain.KillSwitch) error
+	DeleteKillSwitch(ctx context.Context, provider domain.Provider, model string, expiredOnly bool) (*domain.KillSwitch, error)
+	CreateAuditLog(ctx context.Context, log *domain.AuditLog) error
+}
+
+// refreshInterval bounds how long a switch set on another instance takes to apply here
+const refreshInterval = 5 * time.Second
+
+// Actor is who changed a switch, for the audit log
+type Actor struct {
+	ID        string
+	Email     string
+	IPAddress string
+	UserAgent string
+}
+
+// Service answers kill switch checks from a cached copy of the stored switches
+type Service struct {
+	store Store
+
+	mu       sync.RWMutex
+	switches map[string]*domain.KillSwitch // Target -> switch
+}
+
+// NewService creates a new kill switch service
+func NewService(store Store) *Service {
+	return &Service{store: store, switches: make(map[string]*domain.KillSwitch)}
+}
+
+// Check returns the switch stopping traffic to a model of a provider, or nil.
+// A switch on the whole provider wins over one on the model. An empty model
+// checks the provider only.
+func (s *Service) Check(provider domain.Provider, model string) *domain.KillSwitch {
+	if s == nil {
+		return nil
+	}
+	now := time.Now()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if ks, ok := s.switches[string(provider)]; ok && ks.Active(now) {
+		return ks
+	}
+	if model == "" {
+		return nil
+	}
+	target := (&domain.KillSwitch{Provider: provider, Model: domain.KillSwitchModel(provider, model)}).Target()
+	if ks, ok := s.switches[target]; ok && ks.Active(now) {
+		return ks
+	}
+	return nil
+}
+
+// Disabled reports whether traffic to a provider or model is stopped
+// (implements routing.KillSwitch)
+func (s *Service) Disabled(provider, model string) bool {
+	return s.Check(domain.Provider(provider), model) != nil
+}
+
+// List returns the switches in effect, newest first
+func (s *Service) List(ctx context.Context) ([]*domain.KillSwitch, error) {
+	if err := s.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var active []*domain.KillSwitch
+	for _, ks := range s.ordered() {
+		if ks.Active(now) {
+			active = append(active, ks)
+		}
+	}
+	return active, nil
+}
+
+// Disable stops traffic to ks.Provider, or to ks.Model of it, until the switch
+// is cleared or ks.ExpiresAt passes
+func (s *Service) Disable(ctx context.Context, ks domain.KillSwitch, actor Actor) (*domain.KillSwitch, error) {
+	if ks.Provider == "" {
+		return nil, fmt.Errorf("provider is required")
+	}
+	ks.Model = domain.KillSwitchModel(ks.Provider, ks.Model)
+	ks.DisabledBy = actor.Email
+	ks.DisabledAt = time.Now()
+	if ks.ExpiresAt != nil && !ks.ExpiresAt.After(ks.DisabledAt) {
+		return nil, fmt.Errorf("expiresAt must be in the future")
+	}
+	if err := s.store.SaveKillSwitch(ctx, &ks); err != nil {
+		return nil, fmt.Errorf("save kill switch: %w", err)
+	}
+
+	s.mu.Lock()
+	s.switches[ks.Target()] = &ks
+	s.mu.Unlock()
+
+	details := map[string]any{"reason": ks.Reason}
+	if ks.ExpiresAt != nil {
+		details["expires_at"] = ks.ExpiresAt
+	}
+	s.audit(ctx, domain.AuditActionDisable, &ks, actor, details)
+	slog.Warn("Kill switch set", "target", ks.Target(), "reason", ks.Reason, "by", actor.Email, "expires_at", ks.ExpiresAt)
+	return &ks, nil
+}
+
+// Enable clears the switch on a provider or model and reports whether there was one
+func (s *Service) Enable(ctx context.Context, provider domain.Provider, model string, actor Actor) (bool, error) {
+	model = domain.KillSwitchModel(provider, model)
+	ks, err := s.store.DeleteKillSwitch(ctx, provider, model, false)
+	if err != nil {
+		return false, fmt.Errorf("delete kill switch: %w", err)
+	}
+	target := (&domain.KillSwitch{Provider: provider, Model: model}).Target()
+	s.mu.Lock()
+	delete(s.switches, target)
+	s.mu.Unlock()
+	if ks == nil {
+		return false, nil
+	}
+
+	s.audit(ctx, domain.AuditActionEnable, ks, actor, nil)
+	slog.Info("Kill switch cleared", "target", target, "by", actor.Email)
+	return true, nil
+}
+
+// Refresh reloads the switches from the store, clearing the ones that expired
+func (s *Service) Refresh(ctx context.Context) error {
+	switches, err := s.store.ListKillSwitches(ctx)
+	if err != nil {
+		return fmt.Errorf("list kill switches: %w", err)
+	}
+
+	now := time.Now()
+	loaded := make(map[string]*domain.KillSwitch, len(switches))
+	for _, ks := range switches {
+		if !ks.Active(now) {
+			s.expire(ctx, ks)
+			continue
+		}
+		loaded[ks.Target()] = ks
+	}
+
+	s.mu.Lock()
+	s.switches = loaded
+	s.mu.Unlock()
+	return nil
+}
+
+// Start loads the switches and keeps them fresh until ctx is done
+func (s *Service) Start(ctx context.Context) {
+	if err := s.Refresh(ctx); err != nil {
+		slog.Warn("Failed to load kill switches", "error", err)
+	}
+	go func() {
+		ticker := time.NewTicker(refreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.Refresh(ctx); err != nil {
+					slog.Warn("Failed to refresh kill switches", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// expire clears a switch whose timer ran out. Only the instance that deletes
+// it records the re-enable.
+func (s *Service) expire(ctx context.Context, ks *domain.KillSwitch) {
+	cleared, err := s.store.DeleteKillSwitch(ctx, ks.Provider, ks.Model, true)
+	if err != nil {
+		slog.Warn("Failed to clear expired kill switch", "target", ks.Target(), "error", err)
+		return
+	}
+	if cleared != nil {
+		s.audit(ctx, domain.AuditActionEnable, cleared, Actor{ID: "system", Email: "system"}, map[string]any{"expired": true})
+		slog.Info("Kill switch expired, traffic resumed", "target", ks.Target())
+	}
+}
+
+func (s *Service) audit(ctx context.Context, action domain.AuditAction, ks *domain.KillSwitch, actor Actor, details map[string]any) {
+	actorType := "admin"
+	if actor.ID == "system" {
+		actorType = "system"
+	}
+	entry := &domain.AuditLog{
+		Action:       action,
+		ResourceType: domain.AuditResourceKillSwitch,
+		ResourceID:   ks.Target(),
+		ResourceName: ks.Target(),
+		ActorID:      actor.ID,
+		ActorEmail:   actor.Email,
+		ActorType:    actorType,
+		IPAddress:    actor.IPAddress,
+		UserAgent:    actor.UserAgent,
+		Details:      details,
+		Status:       "success",
+	}
+	if err := s.store.CreateAuditLog(context.WithoutCancel(ctx), entry); err != nil {
+		slog.Error("Failed to audit kill switch change", "target", ks.Target(), "error", err)
+	}
+}
+
+// ordered returns the cached switches newest first. Callers hold s.mu.
+func (s *Service) ordered() []*domain.KillSwitch {
+	list := make([]*domain.KillSwitch, 0, len(s.switches))
+	for _, ks := range s.switches {
+		list = append(list, ks)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].DisabledAt.After(list[j].DisabledAt) })
+	return list
+}
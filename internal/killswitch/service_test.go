@@ -0,0 +1,97 @@
+package killswitch
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+type memStore struct {
+	switches map[string]*domain.KillSwitch
+	audits   []*domain.AuditLog
+}
+
+func (m *memStore) ListKillSwitches(ctx context.Context) ([]*domain.KillSwitch, error) {
+	var list []*domain.KillSwitch
+	for _, ks := range m.switches {
+		copied := *ks
+		list = append(list, &copied)
+	}
+	return list, nil
+}
+
+func (m *memStore) SaveKillSwitch(ctx context.Context, ks *domain.KillSwitch) error {
+	copied := *ks
+	m.switches[ks.Target()] = &copied
+	return nil
+}
+
+func (m *memStore) DeleteKillSwitch(ctx context.Context, provider domain.Provider, model string, expiredOnly bool) (*domain.KillSwitch, error) {
+	target := (&domain.KillSwitch{Provider: provider, Model: model}).Target()
+	ks, ok := m.switches[target]
+	if !ok || (expiredOnly && ks.Active(time.Now())) {
+		return nil, nil
+	}
+	delete(m.switches, target)
+	return ks, nil
+}
+
+func (m *memStore) CreateAuditLog(ctx context.Context, log *domain.AuditLog) error {
+	m.audits = append(m.audits, log)
+	return nil
+}
+
+func TestKillSwitch(t *testing.T) {
+	ctx := context.Background()
+	store := &memStore{switches: make(map[string]*domain.KillSwitch)}
+	svc := NewService(store)
+	admin := Actor{ID: "u1", Email: "admin@example.com"}
+
+	// A model switch stops only that model, with or without the provider prefix
+	if _, err := svc.Disable(ctx, domain.KillSwitch{Provider: "openai", Model: "openai/gpt-4o", Reason: "bad outputs"}, admin); err != nil {
+		t.Fatal(err)
+	}
+	if ks := svc.Check("openai", "gpt-4o"); ks == nil || ks.Reason != "bad outputs" {
+		t.Fatalf("expected gpt-4o to be disabled, got %+v", ks)
+	}
+	if svc.Disabled("openai", "gpt-4o-mini") || svc.Disabled("openai", "") {
+		t.Fatal("expected other models of the provider to stay enabled")
+	}
+
+	// A provider switch stops all its models
+	if _, err := svc.Disable(ctx, domain.KillSwitch{Provider: "anthropic", Reason: "outage"}, admin); err != nil {
+		t.Fatal(err)
+	}
+	if !svc.Disabled("anthropic", "claude-sonnet-4") || !svc.Disabled("anthropic", "") {
+		t.Fatal("expected the whole provider to be disabled")
+	}
+
+	// Clearing a switch resumes traffic at once
+	if cleared, err := svc.Enable(ctx, "anthropic", "", admin); err != nil || !cleared {
+		t.Fatalf("expected the switch to be cleared, got %v, %v", cleared, err)
+	}
+	if svc.Disabled("anthropic", "claude-sonnet-4") {
+		t.Fatal("expected the provider to be enabled again")
+	}
+
+	// Expired switches stop applying and are cleared on refresh
+	past := time.Now().Add(-time.Minute)
+	store.switches["openai/gpt-4o"].ExpiresAt = &past
+	if err := svc.Refresh(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if svc.Disabled("openai", "gpt-4o") || len(store.switches) != 0 {
+		t.Fatal("expected the expired switch to be cleared")
+	}
+
+	// Every change is audited: two disables, a manual and a timed re-enable
+	if len(store.audits) != 4 || store.audits[3].ActorType != "system" {
+		t.Fatalf("expected 4 audit entries ending with a system re-enable, got %d", len(store.audits))
+	}
+
+	if _, err := svc.Disable(ctx, domain.KillSwitch{Provider: "openai", Reason: "x", ExpiresAt: &past}, admin); err == nil {
+		t.Fatal("expected an expiry in the past to be rejected")
+	}
+}
@@ -0,0 +1,225 @@
+// Package litellm imports a LiteLLM proxy configuration into ModelGate: its
+// model list becomes providers, custom models and model configs, its teams
+// and virtual keys become roles and API keys, and their budgets become
+// budget policies and API key budgets.
+package litellm
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"modelgate/internal/domain"
+)
+
+// Config is the part of a LiteLLM config.yaml the importer reads. Teams and
+// virtual keys live in LiteLLM's database rather than its config file; they
+// are read from "teams" and "keys" sections holding the objects returned by
+// LiteLLM's /team/list and /key/list endpoints.
+type Config struct {
+	ModelList       []ModelEntry    `yaml:"model_list"`
+	RouterSettings  RouterSettings  `yaml:"router_settings"`
+	LiteLLMSettings LiteLLMSettings `yaml:"litellm_settings"`
+	Teams           []Team          `yaml:"teams"`
+	Keys            []VirtualKey    `yaml:"keys"`
+}
+
+// ModelEntry is a deployment in model_list. Clients call it by ModelName;
+// several entries with the same name are load balanced by LiteLLM.
+type ModelEntry struct {
+	ModelName string        `yaml:"model_name"`
+	Params    LiteLLMParams `yaml:"litellm_params"`
+	ModelInfo ModelInfo     `yaml:"model_info"`
+}
+
+// LiteLLMParams are the provider settings of a deployment
+type LiteLLMParams struct {
+	Model         string `yaml:"model"` // "<provider>/<model>", e.g. azure/gpt-4o-prod
+	APIBase       string `yaml:"api_base"`
+	APIVersion    string `yaml:"api_version"`
+	APIKey        string `yaml:"api_key"`
+	AWSRegionName string `yaml:"aws_region_name"`
+	RPM           int    `yaml:"rpm"`
+	TPM           int    `yaml:"tpm"`
+}
+
+// ModelInfo holds a deployment's pricing and limits
+type ModelInfo struct {
+	InputCostPerToken  float64 `yaml:"input_cost_per_token"`
+	OutputCostPerToken float64 `yaml:"output_cost_per_token"`
+	MaxInputTokens     int     `yaml:"max_input_tokens"`
+	MaxOutputTokens    int     `yaml:"max_output_tokens"`
+}
+
+// RouterSettings are the proxy's routing and retry settings
+type RouterSettings struct {
+	RoutingStrategy string           `yaml:"routing_strategy"`
+	NumRetries      int              `yaml:"num_retries"`
+	Timeout         float64          `yaml:"timeout"` // Seconds
+	Fallbacks       []map[string]any `yaml:"fallbacks"`
+}
+
+// LiteLLMSettings are the proxy-wide settings the importer knows about
+type LiteLLMSettings struct {
+	NumRetries     int              `yaml:"num_retries"`
+	RequestTimeout float64          `yaml:"request_timeout"` // Seconds
+	Fallbacks      []map[string]any `yaml:"fallbacks"`
+	MaxBudget      float64          `yaml:"max_budget"`
+	BudgetDuration string           `yaml:"budget_duration"`
+}
+
+// Team is a LiteLLM team
+type Team struct {
+	TeamID         string   `yaml:"team_id"`
+	TeamAlias      string   `yaml:"team_alias"`
+	Models         []string `yaml:"models"`
+	MaxBudget      float64  `yaml:"max_budget"`
+	BudgetDuration string   `yaml:"budget_duration"`
+	RPMLimit       int      `yaml:"rpm_limit"`
+	TPMLimit       int64    `yaml:"tpm_limit"`
+}
+
+// Name returns the team's alias, or its ID without one
+func (t *Team) Name() string {
+	if t.TeamAlias != "" {
+		return t.TeamAlias
+	}
+	return t.TeamID
+}
+
+// VirtualKey is a LiteLLM virtual key. Token is the SHA-256 hash LiteLLM
+// stores; with only the raw Key, it is hashed on import.
+type VirtualKey struct {
+	Token          string   `yaml:"token"`
+	Key            string   `yaml:"key"`
+	KeyName        string   `yaml:"key_name"` // Masked key, e.g. sk-...1234
+	KeyAlias       string   `yaml:"key_alias"`
+	TeamID         string   `yaml:"team_id"`
+	Models         []string `yaml:"models"`
+	MaxBudget      float64  `yaml:"max_budget"`
+	SoftBudget     float64  `yaml:"soft_budget"`
+	BudgetDuration string   `yaml:"budget_duration"`
+	RPMLimit       int      `yaml:"rpm_limit"`
+	TPMLimit       int64    `yaml:"tpm_limit"`
+	Expires        string   `yaml:"expires"`
+	Blocked        bool     `yaml:"blocked"`
+}
+
+// Parse reads a LiteLLM config.yaml. Settings the importer doesn't use are ignored.
+func Parse(data []byte) (*Config, error) {
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parse LiteLLM config: %w", err)
+	}
+	return &cfg, nil
+}
+
+// providerPrefixes maps LiteLLM provider prefixes to ModelGate providers
+var providerPrefixes = map[string]domain.Provider{
+	"openai":                 domain.ProviderOpenAI,
+	"text-completion-openai": domain.ProviderOpenAI,
+	"azure":                  domain.ProviderAzureOpenAI,
+	"anthropic":              domain.ProviderAnthropic,
+	"bedrock":                domain.ProviderBedrock,
+	"gemini":                 domain.ProviderGemini,
+	"ollama":                 domain.ProviderOllama,
+	"ollama_chat":            domain.ProviderOllama,
+	"groq":                   domain.ProviderGroq,
+	"mistral":                domain.ProviderMistral,
+	"together_ai":            domain.ProviderTogether,
+	"cohere":                 domain.ProviderCohere,
+	"cohere_chat":            domain.ProviderCohere,
+	"openrouter":             domain.ProviderOpenRouter,
+	"xai":                    domain.ProviderXAI,
+	"deepseek":               domain.ProviderDeepSeek,
+	"huggingface":            domain.ProviderHuggingFace,
+	"fireworks_ai":           domain.ProviderFireworks,
+	"hosted_vllm":            domain.ProviderCustom,
+	"openai_like":            domain.ProviderCustom,
+}
+
+// target is where a LiteLLM deployment is served in ModelGate
+type target struct {
+	provider domain.Provider
+	model    string // Model name at the provider
+}
+
+// ModelID returns the "provider/model" ID clients use with ModelGate
+func (t target) ModelID() string {
+	return string(t.provider) + "/" + t.model
+}
+
+// resolveTarget maps a deployment to a ModelGate provider and model. An
+// OpenAI deployment with its own api_base is an OpenAI-compatible server,
+// which ModelGate serves as a custom model.
+func resolveTarget(p LiteLLMParams) (target, error) {
+	prefix, model, ok := strings.Cut(p.Model, "/")
+	if !ok {
+		// LiteLLM infers the provider of well-known model names
+		prefix, model = inferProvider(p.Model), p.Model
+	}
+	provider, known := providerPrefixes[prefix]
+	if !known || model == "" {
+		return target{}, fmt.Errorf("provider of model %q is not supported", p.Model)
+	}
+	if provider == domain.ProviderOpenAI && p.APIBase != "" && !strings.Contains(p.APIBase, "api.openai.com") {
+		provider = domain.ProviderCustom
+	}
+	return target{provider: provider, model: model}, nil
+}
+
+func inferProvider(model string) string {
+	switch {
+	case strings.HasPrefix(model, "gpt-"), strings.HasPrefix(model, "o1"), strings.HasPrefix(model, "o3"),
+		strings.HasPrefix(model, "o4"), strings.HasPrefix(model, "text-embedding-"):
+		return "openai"
+	case strings.HasPrefix(model, "claude"):
+		return "anthropic"
+	case strings.HasPrefix(model, "gemini"):
+		return "gemini"
+	}
+	return ""
+}
+
+// budgetWindow maps a LiteLLM budget_duration ("1d", "24h", "7d", "30d",
+// "1mo", ...) to the budget window it comes closest to
+func budgetWindow(duration string) (string, error) {
+	d := strings.TrimSpace(strings.ToLower(duration))
+	if d == "" {
+		// LiteLLM budgets without a duration never reset; a month is the
+		// longest window ModelGate has
+		return "monthly", nil
+	}
+	var n int
+	var unit string
+	if _, err := fmt.Sscanf(d, "%d%s", &n, &unit); err != nil || n <= 0 {
+		return "", fmt.Errorf("unsupported budget duration %q", duration)
+	}
+	var length time.Duration
+	switch unit {
+	case "s":
+		length = time.Duration(n) * time.Second
+	case "m":
+		length = time.Duration(n) * time.Minute
+	case "h":
+		length = time.Duration(n) * time.Hour
+	case "d":
+		length = time.Duration(n) * 24 * time.Hour
+	case "w":
+		length = time.Duration(n) * 7 * 24 * time.Hour
+	case "mo":
+		length = time.Duration(n) * 30 * 24 * time.Hour
+	default:
+		return "", fmt.Errorf("unsupported budget duration %q", duration)
+	}
+	switch {
+	case length <= 24*time.Hour:
+		return "daily", nil
+	case length <= 7*24*time.Hour:
+		return "weekly", nil
+	default:
+		return "monthly", nil
+	}
+}
@@ -0,0 +1,596 @@
+package litellm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"modelgate/internal/configsync"
+	"modelgate/internal/custommodels"
+	"modelgate/internal/domain"
+	"modelgate/internal/keybudget"
+)
+
+// Store is the persistence used by the importer (implemented by postgres.TenantStore)
+type Store interface {
+	configsync.Store
+	custommodels.Store
+	ListModelConfigs(ctx context.Context) ([]*domain.ModelConfig, error)
+	SaveModelConfig(ctx context.Context, config *domain.ModelConfig) error
+	APIKeyHashExists(ctx context.Context, keyHash string) (bool, error)
+	ImportAPIKey(ctx context.Context, key *domain.APIKey) error
+}
+
+// Kinds of the changes an import makes besides config sync's roles and providers
+const (
+	KindCustomModel = "custom_model"
+	KindModelConfig = "model_config"
+	KindAPIKey      = "api_key"
+)
+
+// DefaultRoleName is the role of imported keys that have no team and no
+// limits of their own
+const DefaultRoleName = "litellm-default"
+
+// Options control an import
+type Options struct {
+	DryRun bool // Report the changes without making them
+
+	// Recorded as the creator of new roles, groups and keys
+	ActorID    string
+	ActorEmail string
+}
+
+// Report is the outcome of an import
+type Report struct {
+	Changes []configsync.Change
+	// Aliases maps the model names LiteLLM clients use to ModelGate model
+	// IDs. Aliases are set in config.toml, so they are reported rather
+	// than applied.
+	Aliases map[string]string
+	// Warnings are the settings that were not imported, or only approximately
+	Warnings []string
+}
+
+// Importer imports LiteLLM configurations
+type Importer struct {
+	store        Store
+	syncer       *configsync.Syncer
+	customModels *custommodels.Service
+}
+
+// NewImporter creates an importer
+func NewImporter(store Store) *Importer {
+	return &Importer{
+		store:        store,
+		syncer:       configsync.NewSyncer(store),
+		customModels: custommodels.NewService(store),
+	}
+}
+
+// plan is an import worked out from a LiteLLM config before anything is read
+// from or written to the database
+type plan struct {
+	doc          configsync.Document
+	customModels []*domain.CustomModel
+	modelConfigs []*domain.ModelConfig
+	keys         []plannedKey
+	aliases      map[string]string
+	warnings     []string
+}
+
+type plannedKey struct {
+	key      *domain.APIKey
+	roleName string
+}
+
+func (p *plan) warn(format string, args ...any) {
+	p.warnings = append(p.warnings, fmt.Sprintf(format, args...))
+}
+
+// Import creates the providers, custom models, model configs, roles and API
+// keys described by a LiteLLM config. Roles and providers are applied with
+// config sync and matched by name, so importing the same config again
+// changes nothing; keys, custom models and model configs that already exist
+// are left alone. Everything is validated before the first write.
+func (i *Importer) Import(ctx context.Context, cfg *Config, opts Options) (*Report, error) {
+	p, err := buildPlan(cfg)
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{Aliases: p.aliases, Warnings: p.warnings}
+
+	// Roles and providers are validated by a dry run of config sync
+	syncOpts := configsync.Options{DryRun: true, ActorID: opts.ActorID, ActorEmail: opts.ActorEmail}
+	syncChanges, err := i.syncer.Apply(ctx, &p.doc, syncOpts)
+	if err != nil {
+		return nil, err
+	}
+	steps, err := i.planWrites(ctx, p, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.DryRun {
+		report.Changes = syncChanges
+		for _, st := range steps {
+			report.Changes = append(report.Changes, st.change)
+		}
+		return report, nil
+	}
+
+	syncOpts.DryRun = false
+	if report.Changes, err = i.syncer.Apply(ctx, &p.doc, syncOpts); err != nil {
+		return report, err
+	}
+	roles, err := i.store.ListRoles(ctx)
+	if err != nil {
+		return report, fmt.Errorf("list roles: %w", err)
+	}
+	roleIDs := make(map[string]string, len(roles))
+	for _, r := range roles {
+		roleIDs[r.Name] = r.ID
+	}
+	for _, st := range steps {
+		change, err := st.apply(ctx, roleIDs)
+		if err != nil {
+			return report, fmt.Errorf("%s: %w", st.change, err)
+		}
+		report.Changes = append(report.Changes, change)
+	}
+	return report, nil
+}
+
+// writeStep is a planned change outside config sync and the write that makes it
+type writeStep struct {
+	change configsync.Change
+	apply  func(ctx context.Context, roleIDs map[string]string) (configsync.Change, error)
+}
+
+// planWrites plans the custom models, model configs and keys that don't exist yet
+func (i *Importer) planWrites(ctx context.Context, p *plan, opts Options) ([]writeStep, error) {
+	var steps []writeStep
+
+	existingCustom, err := i.store.ListCustomModels(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list custom models: %w", err)
+	}
+	for _, m := range p.customModels {
+		if slices.ContainsFunc(existingCustom, func(e *domain.CustomModel) bool { return e.ModelID == m.ModelID }) {
+			continue
+		}
+		change := configsync.Change{Kind: KindCustomModel, Name: m.ModelID, Action: configsync.ActionCreate}
+		steps = append(steps, writeStep{change: change, apply: func(ctx context.Context, _ map[string]string) (configsync.Change, error) {
+			if err := i.customModels.Register(ctx, m); err != nil {
+				return change, err
+			}
+			change.ID = m.ID
+			return change, nil
+		}})
+	}
+
+	existingConfigs, err := i.store.ListModelConfigs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list model configs: %w", err)
+	}
+	for _, mc := range p.modelConfigs {
+		change := configsync.Change{Kind: KindModelConfig, Name: mc.ModelID, Action: configsync.ActionCreate}
+		idx := slices.IndexFunc(existingConfigs, func(e *domain.ModelConfig) bool { return e.ModelID == mc.ModelID })
+		if idx >= 0 {
+			current := existingConfigs[idx]
+			if current.IsEnabled && current.Alias == mc.Alias {
+				continue
+			}
+			// Keep the admin's other settings of the model
+			updated := *current
+			updated.IsEnabled, updated.Alias = true, mc.Alias
+			mc = &updated
+			change.ID, change.Action, change.Fields = current.ID, configsync.ActionUpdate, []string{"alias", "is_enabled"}
+		}
+		steps = append(steps, writeStep{change: change, apply: func(ctx context.Context, _ map[string]string) (configsync.Change, error) {
+			if err := i.store.SaveModelConfig(ctx, mc); err != nil {
+				return change, err
+			}
+			change.ID = mc.ID
+			return change, nil
+		}})
+	}
+
+	for _, pk := range p.keys {
+		exists, err := i.store.APIKeyHashExists(ctx, pk.key.KeyHash)
+		if err != nil {
+			return nil, fmt.Errorf("look up API key %q: %w", pk.key.Name, err)
+		}
+		if exists {
+			continue
+		}
+		key, roleName := pk.key, pk.roleName
+		key.CreatedByEmail = opts.ActorEmail
+		change := configsync.Change{Kind: KindAPIKey, Name: key.Name, Action: configsync.ActionCreate}
+		steps = append(steps, writeStep{change: change, apply: func(ctx context.Context, roleIDs map[string]string) (configsync.Change, error) {
+			key.RoleID = roleIDs[roleName]
+			if key.RoleID == "" {
+				return change, fmt.Errorf("role %q was not created", roleName)
+			}
+			if err := i.store.ImportAPIKey(ctx, key); err != nil {
+				return change, err
+			}
+			change.ID = key.ID
+			return change, nil
+		}})
+	}
+	return steps, nil
+}
+
+// buildPlan converts a LiteLLM config into ModelGate entities
+func buildPlan(cfg *Config) (*plan, error) {
+	p := &plan{aliases: make(map[string]string)}
+
+	targets, err := p.planModels(cfg.ModelList)
+	if err != nil {
+		return nil, err
+	}
+	resilience := p.planResilience(cfg)
+	if cfg.LiteLLMSettings.MaxBudget > 0 {
+		p.warn("the proxy-wide max_budget of $%.2f is not imported; set a tenant cost quota with setQuotaLimits", cfg.LiteLLMSettings.MaxBudget)
+	}
+
+	// allowedModels lists both the LiteLLM names and the ModelGate IDs, so
+	// restrictions hold whether clients use an alias or the model ID
+	allowedModels := func(owner string, models []string) []string {
+		var allowed []string
+		for _, m := range models {
+			if m == "all-proxy-models" || m == "*" {
+				return nil
+			}
+			if strings.Contains(m, "*") {
+				p.warn("%s: wildcard model %q is not supported in allowed models", owner, m)
+				continue
+			}
+			allowed = append(allowed, m)
+			if id, ok := targets[m]; ok && id != m {
+				allowed = append(allowed, id)
+			}
+		}
+		return allowed
+	}
+
+	teamRoles := make(map[string]string, len(cfg.Teams))
+	roleNames := make(map[string]bool)
+	addRole := func(name, description string, policy map[string]any) error {
+		if roleNames[name] {
+			return fmt.Errorf("two teams or keys import as role %q", name)
+		}
+		roleNames[name] = true
+		if resilience != nil {
+			policy["resilience_policy"] = resilience
+		}
+		p.doc.Roles = append(p.doc.Roles, mustMarshal(map[string]any{
+			"name":        name,
+			"description": description,
+			"policy":      policy,
+		}))
+		return nil
+	}
+
+	for i, team := range cfg.Teams {
+		name := team.Name()
+		if name == "" {
+			return nil, fmt.Errorf("teams[%d]: team has no team_id or team_alias", i)
+		}
+		policy := map[string]any{
+			"model_restrictions": map[string]any{"allowed_models": allowedModels("team "+name, team.Models)},
+			"rate_limit_policy":  map[string]any{"requests_per_minute": team.RPMLimit, "tokens_per_minute": team.TPMLimit},
+		}
+		if team.MaxBudget > 0 {
+			window, err := budgetWindow(team.BudgetDuration)
+			if err != nil {
+				return nil, fmt.Errorf("team %q: %w", name, err)
+			}
+			policy["budget_policy"] = map[string]any{
+				"enabled":             true,
+				window + "_limit_usd": team.MaxBudget,
+				"on_exceeded":         domain.BudgetActionBlock,
+			}
+		}
+		if err := addRole(name, "Imported from LiteLLM team "+team.TeamID, policy); err != nil {
+			return nil, err
+		}
+		if team.TeamID != "" {
+			teamRoles[team.TeamID] = name
+		}
+	}
+
+	usesDefaultRole := false
+	seenHashes := make(map[string]bool, len(cfg.Keys))
+	for i, vk := range cfg.Keys {
+		key, err := p.planKey(i, vk)
+		if err != nil {
+			return nil, err
+		}
+		if key == nil || seenHashes[key.KeyHash] {
+			continue
+		}
+		seenHashes[key.KeyHash] = true
+
+		roleName := DefaultRoleName
+		if vk.TeamID != "" {
+			name, ok := teamRoles[vk.TeamID]
+			if !ok {
+				return nil, fmt.Errorf("key %q: team %q is not in the teams section", key.Name, vk.TeamID)
+			}
+			roleName = name
+		}
+		if len(vk.Models) > 0 || vk.RPMLimit > 0 || vk.TPMLimit > 0 {
+			// Limits of the key itself need a role of its own
+			if vk.TeamID != "" {
+				p.warn("key %q: it gets a role of its own for its limits, so team %q's budget no longer applies to it", key.Name, vk.TeamID)
+			}
+			roleName = "litellm-key-" + roleSlug(key.Name)
+			policy := map[string]any{
+				"model_restrictions": map[string]any{"allowed_models": allowedModels("key "+key.Name, vk.Models)},
+				"rate_limit_policy":  map[string]any{"requests_per_minute": vk.RPMLimit, "tokens_per_minute": vk.TPMLimit},
+			}
+			if err := addRole(roleName, "Imported from the limits of LiteLLM key "+key.Name, policy); err != nil {
+				return nil, err
+			}
+		}
+		usesDefaultRole = usesDefaultRole || roleName == DefaultRoleName
+		p.keys = append(p.keys, plannedKey{key: key, roleName: roleName})
+	}
+	if usesDefaultRole {
+		if err := addRole(DefaultRoleName, "Imported LiteLLM keys without a team", map[string]any{}); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// planModels plans the providers, custom models and model configs of the
+// model list and returns the ModelGate model ID of each LiteLLM model name
+func (p *plan) planModels(entries []ModelEntry) (map[string]string, error) {
+	targets := make(map[string]string)
+	providers := make(map[domain.Provider]map[string]any)
+	var providerOrder []domain.Provider
+	needsKey := make(map[domain.Provider]bool)
+
+	for i, entry := range entries {
+		if entry.ModelName == "" || entry.Params.Model == "" {
+			return nil, fmt.Errorf("model_list[%d]: model_name and litellm_params.model are required", i)
+		}
+		t, err := resolveTarget(entry.Params)
+		if err != nil {
+			p.warn("model %q: %v; skipped", entry.ModelName, err)
+			continue
+		}
+		if _, dup := targets[entry.ModelName]; dup {
+			p.warn("model %q: only the first deployment is imported; LiteLLM load balancing across deployments is not", entry.ModelName)
+			continue
+		}
+
+		if t.provider == domain.ProviderCustom {
+			m, err := customModel(entry, t)
+			if err != nil {
+				return nil, fmt.Errorf("model %q: %w", entry.ModelName, err)
+			}
+			p.customModels = append(p.customModels, m)
+			targets[entry.ModelName] = string(domain.ProviderCustom) + "/" + m.ModelID
+			if m.ModelID != entry.ModelName {
+				p.aliases[entry.ModelName] = targets[entry.ModelName]
+			}
+			continue
+		}
+
+		targets[entry.ModelName] = t.ModelID()
+		if entry.ModelName != t.ModelID() {
+			p.aliases[entry.ModelName] = t.ModelID()
+		}
+		p.modelConfigs = append(p.modelConfigs, &domain.ModelConfig{
+			ModelID:        t.ModelID(),
+			IsEnabled:      true,
+			Alias:          entry.ModelName,
+			CostMultiplier: 1,
+		})
+
+		spec, ok := providers[t.provider]
+		if !ok {
+			spec = map[string]any{"provider": t.provider, "enabled": true}
+			providers[t.provider] = spec
+			providerOrder = append(providerOrder, t.provider)
+		}
+		if entry.Params.APIBase != "" {
+			if base, set := spec["base_url"]; set && base != entry.Params.APIBase {
+				p.warn("model %q: provider %s already uses api_base %s; %s is not imported", entry.ModelName, t.provider, base, entry.Params.APIBase)
+			} else {
+				spec["base_url"] = entry.Params.APIBase
+			}
+		}
+		if entry.Params.APIVersion != "" {
+			spec["api_version"] = entry.Params.APIVersion
+		}
+		if entry.Params.AWSRegionName != "" {
+			spec["region"] = entry.Params.AWSRegionName
+		}
+		if entry.Params.TPM > 0 {
+			throttles, _ := spec["throttles"].([]domain.ProviderThrottle)
+			spec["throttles"] = append(throttles, domain.ProviderThrottle{Model: t.model, TokensPerMinute: entry.Params.TPM})
+		}
+		if entry.Params.RPM > 0 {
+			p.warn("model %q: the deployment's rpm limit is not imported; ModelGate limits requests per role", entry.ModelName)
+		}
+		if entry.Params.APIKey != "" && t.provider.RequiresAPIKey() {
+			needsKey[t.provider] = true
+		}
+	}
+
+	for _, provider := range providerOrder {
+		p.doc.Providers = append(p.doc.Providers, mustMarshal(providers[provider]))
+		if needsKey[provider] {
+			p.warn("provider %s: API keys are not imported; set one with `modelgate provider set-key -provider %s`", provider, provider)
+		}
+	}
+	return targets, nil
+}
+
+// customModel describes an OpenAI-compatible deployment as a custom model
+func customModel(entry ModelEntry, t target) (*domain.CustomModel, error) {
+	u, err := url.Parse(entry.Params.APIBase)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("api_base %q is not an http(s) URL", entry.Params.APIBase)
+	}
+	info := entry.ModelInfo
+	// LiteLLM passes tools and streaming through to OpenAI-compatible
+	// servers, so the model is registered as supporting both
+	return &domain.CustomModel{
+		ModelID:           strings.Join(strings.Fields(entry.ModelName), "-"),
+		Name:              entry.ModelName,
+		BaseURL:           strings.TrimRight(entry.Params.APIBase, "/"),
+		UpstreamModel:     t.model,
+		ContextWindow:     info.MaxInputTokens,
+		MaxOutputTokens:   info.MaxOutputTokens,
+		InputCostPer1M:    perMillion(info.InputCostPerToken),
+		OutputCostPer1M:   perMillion(info.OutputCostPerToken),
+		SupportsTools:     true,
+		SupportsStreaming: true,
+		Enabled:           true,
+	}, nil
+}
+
+// perMillion converts a per-token price to a per-million one, rounded to a
+// millionth of a dollar to drop float noise
+func perMillion(perToken float64) float64 {
+	return math.Round(perToken*1e12) / 1e6
+}
+
+// planResilience maps the router's retries and timeout to a resilience policy
+// for the imported roles; nil when the config sets neither
+func (p *plan) planResilience(cfg *Config) map[string]any {
+	router, settings := cfg.RouterSettings, cfg.LiteLLMSettings
+	if s := router.RoutingStrategy; s != "" && s != "simple-shuffle" {
+		p.warn("routing_strategy %q is not imported; ModelGate routes with each role's routing policy", s)
+	}
+	if len(router.Fallbacks) > 0 || len(settings.Fallbacks) > 0 {
+		p.warn("per-model fallbacks are not imported; set a fallback chain in the roles' resilience policy")
+	}
+
+	retries := router.NumRetries
+	if retries == 0 {
+		retries = settings.NumRetries
+	}
+	timeout := router.Timeout
+	if timeout == 0 {
+		timeout = settings.RequestTimeout
+	}
+	if retries == 0 && timeout == 0 {
+		return nil
+	}
+	policy := map[string]any{"enabled": true}
+	if retries > 0 {
+		policy["retry_enabled"] = true
+		policy["max_retries"] = retries
+		policy["retry_on_timeout"] = true
+		policy["retry_on_rate_limit"] = true
+		policy["retry_on_server_error"] = true
+	}
+	if timeout > 0 {
+		policy["request_timeout_ms"] = int(timeout * 1000)
+	}
+	return policy
+}
+
+// planKey converts a virtual key; blocked keys are skipped (nil)
+func (p *plan) planKey(i int, vk VirtualKey) (*domain.APIKey, error) {
+	keyHash := strings.ToLower(vk.Token)
+	prefix := vk.KeyName
+	switch {
+	case vk.Key != "":
+		h := sha256.Sum256([]byte(vk.Key))
+		keyHash = hex.EncodeToString(h[:])
+		prefix = vk.Key[:min(len(vk.Key), 11)]
+	case !sha256Hex.MatchString(keyHash):
+		return nil, fmt.Errorf("keys[%d]: a key needs its token (the SHA-256 hash LiteLLM stores) or key", i)
+	}
+	if prefix == "" {
+		prefix = "sk-"
+	}
+	name := vk.KeyAlias
+	if name == "" {
+		name = "litellm-" + keyHash[:8]
+	}
+	if vk.Blocked {
+		p.warn("key %q: blocked in LiteLLM; skipped", name)
+		return nil, nil
+	}
+
+	key := &domain.APIKey{
+		Name:      name,
+		KeyPrefix: prefix[:min(len(prefix), 20)],
+		KeyHash:   keyHash,
+		Scopes:    []string{},
+	}
+	if vk.Expires != "" {
+		expires, err := time.Parse(time.RFC3339, vk.Expires)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: expires: %w", name, err)
+		}
+		key.ExpiresAt = &expires
+	}
+
+	if vk.MaxBudget > 0 || vk.SoftBudget > 0 {
+		window, err := budgetWindow(vk.BudgetDuration)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", name, err)
+		}
+		switch window {
+		case "daily":
+			key.Budget = &domain.APIKeyBudget{DailyHardLimitUSD: vk.MaxBudget, DailySoftLimitUSD: vk.SoftBudget}
+		case "monthly":
+			key.Budget = &domain.APIKeyBudget{MonthlyHardLimitUSD: vk.MaxBudget, MonthlySoftLimitUSD: vk.SoftBudget}
+		default:
+			p.warn("key %q: API key budgets are daily or monthly; the %s budget of $%.2f is not imported", name, window, vk.MaxBudget)
+		}
+		if key.Budget != nil {
+			if err := keybudget.Validate(key.Budget); err != nil {
+				return nil, fmt.Errorf("key %q: %w", name, err)
+			}
+		}
+	}
+	return key, nil
+}
+
+var (
+	sha256Hex   = regexp.MustCompile(`^[0-9a-f]{64}$`)
+	nonSlugChar = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// roleSlug turns a key name into part of a role name
+func roleSlug(name string) string {
+	return strings.Trim(nonSlugChar.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+// SortedAliases returns the report's aliases as config.toml lines
+func (r *Report) SortedAliases() []string {
+	lines := make([]string, 0, len(r.Aliases))
+	for name, id := range r.Aliases {
+		lines = append(lines, fmt.Sprintf("%q = %q", name, id))
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+func mustMarshal(v any) json.RawMessage {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("litellm: marshal %T: %v", v, err))
+	}
+	return data
+}
@@ -0,0 +1,263 @@
+package litellm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"testing"
+
+	"modelgate/internal/domain"
+)
+
+type fakeStore struct {
+	roles        map[string]*domain.Role
+	policies     map[string]*domain.RolePolicy
+	providers    map[domain.Provider]*domain.ProviderConfig
+	customModels []*domain.CustomModel
+	modelConfigs []*domain.ModelConfig
+	keys         []*domain.APIKey
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		roles:     make(map[string]*domain.Role),
+		policies:  make(map[string]*domain.RolePolicy),
+		providers: make(map[domain.Provider]*domain.ProviderConfig),
+	}
+}
+
+func (f *fakeStore) ListRoles(ctx context.Context) ([]*domain.Role, error) {
+	var roles []*domain.Role
+	for _, r := range f.roles {
+		copied := *r
+		roles = append(roles, &copied)
+	}
+	return roles, nil
+}
+
+func (f *fakeStore) GetRolePolicy(ctx context.Context, roleID string) (*domain.RolePolicy, error) {
+	if p, ok := f.policies[roleID]; ok {
+		copied := *p
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (f *fakeStore) CreateRole(ctx context.Context, role *domain.Role) error {
+	f.roles[role.ID] = role
+	return nil
+}
+
+func (f *fakeStore) UpdateRole(ctx context.Context, role *domain.Role) error {
+	f.roles[role.ID] = role
+	return nil
+}
+
+func (f *fakeStore) DeleteRole(ctx context.Context, id string) error {
+	delete(f.roles, id)
+	return nil
+}
+
+func (f *fakeStore) UpdateRolePolicy(ctx context.Context, policy *domain.RolePolicy) error {
+	f.policies[policy.RoleID] = policy
+	return nil
+}
+
+func (f *fakeStore) ListGroups(ctx context.Context) ([]*domain.Group, error)         { return nil, nil }
+func (f *fakeStore) CreateGroup(ctx context.Context, group *domain.Group) error      { return nil }
+func (f *fakeStore) UpdateGroup(ctx context.Context, group *domain.Group) error      { return nil }
+func (f *fakeStore) DeleteGroup(ctx context.Context, id string) error                { return nil }
+func (f *fakeStore) DeleteProviderModels(ctx context.Context, provider string) error { return nil }
+
+func (f *fakeStore) SaveAvailableModels(ctx context.Context, provider string, models []domain.ModelInfo) error {
+	return nil
+}
+
+func (f *fakeStore) ListProviderConfigs(ctx context.Context) ([]*domain.ProviderConfig, error) {
+	var configs []*domain.ProviderConfig
+	for _, c := range f.providers {
+		copied := *c
+		configs = append(configs, &copied)
+	}
+	return configs, nil
+}
+
+func (f *fakeStore) GetProviderConfig(ctx context.Context, provider domain.Provider) (*domain.ProviderConfig, error) {
+	return f.providers[provider], nil
+}
+
+func (f *fakeStore) SaveProviderConfig(ctx context.Context, config *domain.ProviderConfig) error {
+	f.providers[config.Provider] = config
+	return nil
+}
+
+func (f *fakeStore) CreateCustomModel(ctx context.Context, m *domain.CustomModel) error {
+	m.ID = fmt.Sprintf("cm-%d", len(f.customModels)+1)
+	f.customModels = append(f.customModels, m)
+	return nil
+}
+
+func (f *fakeStore) GetCustomModel(ctx context.Context, id string) (*domain.CustomModel, error) {
+	for _, m := range f.customModels {
+		if m.ID == id {
+			return m, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeStore) ListCustomModels(ctx context.Context) ([]*domain.CustomModel, error) {
+	return f.customModels, nil
+}
+
+func (f *fakeStore) UpdateCustomModel(ctx context.Context, m *domain.CustomModel) error { return nil }
+func (f *fakeStore) DeleteCustomModel(ctx context.Context, id string) (bool, error) {
+	return false, nil
+}
+
+func (f *fakeStore) ListModelConfigs(ctx context.Context) ([]*domain.ModelConfig, error) {
+	return f.modelConfigs, nil
+}
+
+func (f *fakeStore) SaveModelConfig(ctx context.Context, config *domain.ModelConfig) error {
+	config.ID = config.ModelID
+	f.modelConfigs = append(f.modelConfigs, config)
+	return nil
+}
+
+func (f *fakeStore) APIKeyHashExists(ctx context.Context, keyHash string) (bool, error) {
+	return slices.ContainsFunc(f.keys, func(k *domain.APIKey) bool { return k.KeyHash == keyHash }), nil
+}
+
+func (f *fakeStore) ImportAPIKey(ctx context.Context, key *domain.APIKey) error {
+	key.ID = fmt.Sprintf("key-%d", len(f.keys)+1)
+	f.keys = append(f.keys, key)
+	return nil
+}
+
+const testConfig = `
+model_list:
+  - model_name: gpt-4
+    litellm_params:
+      model: azure/gpt-4-prod
+      api_base: https://example.openai.azure.com
+      api_version: "2024-06-01"
+      api_key: os.environ/AZURE_API_KEY
+      tpm: 100000
+  - model_name: claude
+    litellm_params:
+      model: anthropic/claude-sonnet-4
+  - model_name: llama
+    litellm_params:
+      model: openai/meta-llama/Llama-3-8B
+      api_base: http://vllm:8000/v1
+    model_info:
+      input_cost_per_token: 0.0000002
+router_settings:
+  routing_strategy: latency-based-routing
+  num_retries: 2
+  timeout: 30
+teams:
+  - team_id: t1
+    team_alias: search
+    models: [gpt-4]
+    max_budget: 500
+    budget_duration: 30d
+keys:
+  - key: sk-team-key
+    key_alias: search-prod
+    team_id: t1
+  - token: 2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae
+    key_name: sk-...abcd
+    key_alias: intern
+    models: [claude]
+    max_budget: 5
+    soft_budget: 4
+    budget_duration: 1d
+`
+
+func TestImport(t *testing.T) {
+	ctx := context.Background()
+	cfg, err := Parse([]byte(testConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := newFakeStore()
+	importer := NewImporter(store)
+
+	// A dry run reports everything and writes nothing
+	report, err := importer.Import(ctx, cfg, Options{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var changes []string
+	for _, c := range report.Changes {
+		changes = append(changes, c.String())
+	}
+	want := []string{
+		`create role "search"`,
+		`create role "litellm-key-intern"`,
+		`create provider "azure_openai"`,
+		`create provider "anthropic"`,
+		`create custom_model "llama"`,
+		`create model_config "azure_openai/gpt-4-prod"`,
+		`create model_config "anthropic/claude-sonnet-4"`,
+		`create api_key "search-prod"`,
+		`create api_key "intern"`,
+	}
+	if !slices.Equal(changes, want) {
+		t.Fatalf("unexpected changes:\n%v\nwant:\n%v", changes, want)
+	}
+	if len(store.roles) != 0 || len(store.keys) != 0 {
+		t.Fatal("expected a dry run to write nothing")
+	}
+	if report.Aliases["gpt-4"] != "azure_openai/gpt-4-prod" || report.Aliases["claude"] != "anthropic/claude-sonnet-4" {
+		t.Fatalf("unexpected aliases %v", report.Aliases)
+	}
+	if len(report.Warnings) != 2 {
+		t.Fatalf("expected warnings for the routing strategy and the Azure key, got %v", report.Warnings)
+	}
+
+	if _, err := importer.Import(ctx, cfg, Options{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Keys keep their secrets: the raw key is hashed, the token used as is
+	h := sha256.Sum256([]byte("sk-team-key"))
+	search, intern := store.keys[0], store.keys[1]
+	if search.KeyHash != hex.EncodeToString(h[:]) || store.roles[search.RoleID].Name != "search" {
+		t.Fatalf("unexpected team key %+v", search)
+	}
+	if intern.KeyPrefix != "sk-...abcd" || intern.Budget == nil || intern.Budget.DailyHardLimitUSD != 5 || intern.Budget.DailySoftLimitUSD != 4 {
+		t.Fatalf("unexpected key budget %+v", intern)
+	}
+
+	policy := store.policies[search.RoleID]
+	if policy.BudgetPolicy.MonthlyLimitUSD != 500 || policy.BudgetPolicy.OnExceeded != domain.BudgetActionBlock {
+		t.Fatalf("expected a monthly team budget, got %+v", policy.BudgetPolicy)
+	}
+	if !slices.Equal(policy.ModelRestriction.AllowedModels, []string{"gpt-4", "azure_openai/gpt-4-prod"}) {
+		t.Fatalf("unexpected allowed models %v", policy.ModelRestriction.AllowedModels)
+	}
+	if policy.ResiliencePolicy.MaxRetries != 2 || policy.ResiliencePolicy.RequestTimeoutMs != 30000 {
+		t.Fatalf("expected retries and timeout from the router settings, got %+v", policy.ResiliencePolicy)
+	}
+	azure := store.providers[domain.ProviderAzureOpenAI]
+	if azure.BaseURL != "https://example.openai.azure.com" || azure.APIVersion != "2024-06-01" || len(azure.Throttles) != 1 {
+		t.Fatalf("unexpected Azure provider %+v", azure)
+	}
+	if m := store.customModels[0]; m.UpstreamModel != "meta-llama/Llama-3-8B" || m.InputCostPer1M != 0.2 {
+		t.Fatalf("unexpected custom model %+v", m)
+	}
+
+	// Importing again changes nothing
+	report, err = importer.Import(ctx, cfg, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Changes) != 0 {
+		t.Fatalf("expected no changes on a second import, got %v", report.Changes)
+	}
+}
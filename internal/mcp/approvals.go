@@ -0,0 +1,66 @@
+package mcp
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"modelgate/internal/domain"
+	"modelgate/internal/storage/postgres"
+	"modelgate/internal/toolapproval"
+)
+
+// rpcErrToolApprovalPending is the JSON-RPC error code returned when a call
+// is waiting on an admin to approve the tool for the caller's role
+const rpcErrToolApprovalPending = -32030
+
+// ToolApprovalPending is the data of the structured MCP error returned while
+// a tool awaits approval
+type ToolApprovalPending struct {
+	Reason      string `json:"reason"` // Always "approval_pending"
+	Tool        string `json:"tool"`
+	ApprovalID  string `json:"approval_id"`
+	RequestedAt string `json:"requested_at"`
+}
+
+// SetToolApprovals queues calls to tools a role has no permission for
+// instead of denying them outright
+func (s *MCPServer) SetToolApprovals(svc *toolapproval.Service) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.approvals = svc
+}
+
+// requestToolApproval records a denied call for admin review and returns the
+// role's approval request, or nil when approvals are off, the tool was denied
+// explicitly, or the request could not be recorded
+func (s *MCPServer) requestToolApproval(ctx context.Context, store *postgres.TenantStore, client *AuthenticatedClient, server *domain.MCPServer, tool *domain.MCPTool) *domain.MCPToolApproval {
+	s.mu.RLock()
+	approvals := s.approvals
+	s.mu.RUnlock()
+	if approvals == nil || !approvals.Enabled() || client.RoleID == "" {
+		return nil
+	}
+	if perm, err := store.GetMCPToolPermission(ctx, client.RoleID, tool.ID); err != nil || perm != nil {
+		return nil
+	}
+	a, err := approvals.Request(ctx, client.RoleID, client.APIKeyID, server, tool)
+	if err != nil {
+		slog.Error("Failed to queue tool for approval", "tool", tool.Name, "role_id", client.RoleID, "error", err)
+		return nil
+	}
+	return a
+}
+
+func approvalPendingError(toolName string, a *domain.MCPToolApproval) *RPCError {
+	return &RPCError{
+		Code:    rpcErrToolApprovalPending,
+		Message: "Tool " + toolName + " is awaiting admin approval for your role",
+		Data: ToolApprovalPending{
+			Reason:      "approval_pending",
+			Tool:        toolName,
+			ApprovalID:  a.ID,
+			RequestedAt: a.FirstRequestedAt.UTC().Format(time.RFC3339),
+		},
+	}
+}
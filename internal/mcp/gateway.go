@@ -819,6 +819,38 @@ func (g *Gateway) indexToolEmbeddings(ctx context.Context, store *postgres.Tenan
 	return store.UpdateToolEmbeddings(ctx, tool.ID, nameEmb, descEmb, combinedEmb)
 }
 
+// ReindexToolEmbeddings embeds every tool again with the current embedder,
+// after the tool search embedder changed. It returns how many tools were indexed.
+func (g *Gateway) ReindexToolEmbeddings(ctx context.Context) (int, error) {
+	if g.embedder == nil {
+		return 0, fmt.Errorf("embedder not configured")
+	}
+	g.mu.RLock()
+	stores := make([]*postgres.TenantStore, 0, len(g.stores))
+	for _, store := range g.stores {
+		stores = append(stores, store)
+	}
+	g.mu.RUnlock()
+
+	// Results ranked with the old embedder are stale
+	g.searchCache.Clear()
+
+	indexed := 0
+	for _, store := range stores {
+		tools, err := store.ListAllMCPTools(ctx)
+		if err != nil {
+			return indexed, fmt.Errorf("list tools: %w", err)
+		}
+		for _, tool := range tools {
+			if err := g.indexToolEmbeddings(ctx, store, tool); err != nil {
+				return indexed, fmt.Errorf("index tool %s: %w", tool.Name, err)
+			}
+			indexed++
+		}
+	}
+	return indexed, nil
+}
+
 func (g *Gateway) computeChanges(oldTools []domain.MCPTool, newTools []*domain.MCPTool) []domain.MCPSchemaChange {
 	var changes []domain.MCPSchemaChange
 
@@ -999,27 +1031,12 @@ func (g *Gateway) SearchTools(ctx context.Context, tenantSlug string, roleID str
 	}
 
 	// Use semantic search with embeddings for better natural language matching
-	tools, scores, err := g.searchSemantic(ctx, store, req)
+	// Only ALLOW and SEARCH tools are searchable; an empty roleID (admin view) sees every tool
+	tools, scores, err := g.searchSemantic(ctx, store, roleID, req)
 	if err != nil {
 		return nil, err
 	}
 
-	// Filter by visibility - only ALLOW and SEARCH tools are searchable
-	// If roleID is empty (admin view), skip visibility filtering
-	var allowedTools []*domain.MCPTool
-	if roleID == "" {
-		// Admin view - return all tools without visibility filtering
-		allowedTools = tools
-	} else {
-		// Filter by role visibility
-		for _, tool := range tools {
-			visibility := store.GetMCPToolVisibility(ctx, roleID, tool.ID)
-			if visibility == domain.MCPVisibilityAllow || visibility == domain.MCPVisibilitySearch {
-				allowedTools = append(allowedTools, tool)
-			}
-		}
-	}
-
 	// Build results with similarity scores from semantic search
 	// Minimum similarity threshold - tools below this are considered irrelevant
 	minScore := 0.5 // Cosine similarity threshold (0.5 = moderately similar)
@@ -1028,14 +1045,11 @@ func (g *Gateway) SearchTools(ctx context.Context, tenantSlug string, roleID str
 	}
 
 	var results []*domain.ToolSearchResult
-	for _, tool := range allowedTools {
+	for i, tool := range tools {
 		// Get similarity score from semantic search
 		score := 0.0
-		for j, t := range tools {
-			if t.ID == tool.ID && j < len(scores) {
-				score = scores[j]
-				break
-			}
+		if i < len(scores) {
+			score = scores[i]
 		}
 
 		// Only include tools with sufficient similarity
@@ -1071,7 +1085,7 @@ func (g *Gateway) SearchTools(ctx context.Context, tenantSlug string, roleID str
 		Tools:          results,
 		Query:          req.Query,
 		TotalAvailable: len(tools),
-		TotalAllowed:   len(allowedTools),
+		TotalAllowed:   len(tools),
 	}
 
 	// Cache result
@@ -1082,7 +1096,7 @@ func (g *Gateway) SearchTools(ctx context.Context, tenantSlug string, roleID str
 
 // searchSemantic performs vector similarity search using embeddings
 // Returns tools and their similarity scores
-func (g *Gateway) searchSemantic(ctx context.Context, store *postgres.TenantStore, req *domain.ToolSearchRequest) ([]*domain.MCPTool, []float64, error) {
+func (g *Gateway) searchSemantic(ctx context.Context, store *postgres.TenantStore, roleID string, req *domain.ToolSearchRequest) ([]*domain.MCPTool, []float64, error) {
 	if g.embedder == nil {
 		return nil, nil, fmt.Errorf("embedder not configured - semantic search requires an embedding service")
 	}
@@ -1099,7 +1113,7 @@ func (g *Gateway) searchSemantic(ctx context.Context, store *postgres.TenantStor
 	}
 
 	// Search by vector similarity - returns tools sorted by similarity
-	return store.SearchToolsByVectorWithScores(ctx, embedding, maxResults)
+	return store.SearchToolsByVectorWithScores(ctx, embedding, roleID, maxResults)
 }
 
 // GetToolSearchTool returns the special tool_search tool definition
@@ -1197,6 +1211,13 @@ func (c *SearchCache) Set(tenantID, query string, result *domain.ToolSearchRespo
 	}
 }
 
+// Clear drops every cached search result
+func (c *SearchCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cache = make(map[string]*cacheEntry)
+}
+
 func (c *SearchCache) cleanup() {
 	ticker := time.NewTicker(time.Minute)
 	for range ticker.C {
@@ -0,0 +1,137 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"modelgate/internal/domain"
+	"modelgate/internal/storage/postgres"
+)
+
+// rpcErrToolLimitExceeded is the JSON-RPC error code returned when a call is
+// blocked by the role's MCP tool limits
+const rpcErrToolLimitExceeded = -32029
+
+// Reasons a tool call is blocked
+const (
+	ToolLimitPerMinute     = "rate_limit_per_minute"
+	ToolLimitPerDay        = "rate_limit_per_day"
+	ToolLimitCooldown      = "tool_cooldown"
+	ToolLimitDailyBudget   = "daily_budget"
+	ToolLimitMonthlyBudget = "monthly_budget"
+)
+
+// ToolLimitViolation is the data of the structured MCP error returned for a
+// blocked tool call
+type ToolLimitViolation struct {
+	Reason            string  `json:"reason"`
+	Tool              string  `json:"tool"`
+	Limit             float64 `json:"limit"`
+	Used              float64 `json:"used"`
+	RetryAfterSeconds int     `json:"retry_after_seconds"`
+}
+
+// Message describes the violation for the error message and execution log
+func (v *ToolLimitViolation) Message() string {
+	switch v.Reason {
+	case ToolLimitPerMinute:
+		return fmt.Sprintf("Tool call limit of %d per minute reached", int(v.Limit))
+	case ToolLimitPerDay:
+		return fmt.Sprintf("Tool call limit of %d per day reached", int(v.Limit))
+	case ToolLimitCooldown:
+		return fmt.Sprintf("Tool %s is cooling down for %ds", v.Tool, v.RetryAfterSeconds)
+	case ToolLimitDailyBudget:
+		return fmt.Sprintf("Daily tool budget of $%.2f exhausted", v.Limit)
+	case ToolLimitMonthlyBudget:
+		return fmt.Sprintf("Monthly tool budget of $%.2f exhausted", v.Limit)
+	}
+	return "Tool limit exceeded"
+}
+
+func (v *ToolLimitViolation) rpcError() *RPCError {
+	return &RPCError{Code: rpcErrToolLimitExceeded, Message: v.Message(), Data: v}
+}
+
+// checkToolLimits checks a call of tool against the role's MCP limits and
+// returns the cost to charge for it. Limits are skipped when the role's
+// policy or usage can't be loaded, so a database hiccup doesn't take tools
+// offline.
+func (s *MCPServer) checkToolLimits(ctx context.Context, store *postgres.TenantStore, client *AuthenticatedClient, toolID, tool string, now time.Time) (float64, *ToolLimitViolation) {
+	if client.RoleID == "" {
+		return 0, nil
+	}
+	policy, err := store.GetRolePolicy(ctx, client.RoleID)
+	if err != nil {
+		slog.Warn("Failed to load role policy for MCP limits", "role_id", client.RoleID, "error", err)
+		return 0, nil
+	}
+	if policy == nil || !hasToolLimits(&policy.MCPPolicies) {
+		return 0, nil
+	}
+	limits := &policy.MCPPolicies
+	cost := 0.0
+	if l := limits.ToolLimit(tool); l != nil {
+		cost = l.CostPerCallUSD
+	}
+
+	usage, err := store.GetMCPToolUsage(ctx, client.RoleID, toolID, now)
+	if err != nil {
+		slog.Warn("Failed to load MCP tool usage", "role_id", client.RoleID, "error", err)
+		return cost, nil
+	}
+	return cost, evaluateToolLimits(limits, tool, usage, now)
+}
+
+// hasToolLimits reports whether a policy sets any quantitative MCP limit
+func hasToolLimits(p *domain.MCPPolicies) bool {
+	return p.MaxInvocationsPerMinute > 0 || p.MaxInvocationsPerDay > 0 ||
+		p.DailyBudgetUSD > 0 || p.MonthlyBudgetUSD > 0 || len(p.ToolLimits) > 0
+}
+
+// evaluateToolLimits returns the first limit a call of tool would break, or
+// nil. Budgets only apply to tools with a cost per call.
+func evaluateToolLimits(p *domain.MCPPolicies, tool string, usage *domain.MCPToolUsage, now time.Time) *ToolLimitViolation {
+	now = now.UTC()
+	nextDay := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+	nextMonth := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+
+	if p.MaxInvocationsPerMinute > 0 && usage.LastMinute >= p.MaxInvocationsPerMinute {
+		return violation(ToolLimitPerMinute, tool, float64(p.MaxInvocationsPerMinute), float64(usage.LastMinute), time.Minute)
+	}
+	if p.MaxInvocationsPerDay > 0 && usage.Today >= p.MaxInvocationsPerDay {
+		return violation(ToolLimitPerDay, tool, float64(p.MaxInvocationsPerDay), float64(usage.Today), nextDay.Sub(now))
+	}
+
+	l := p.ToolLimit(tool)
+	if l == nil {
+		return nil
+	}
+	if l.CooldownSeconds > 0 && usage.LastToolCall != nil {
+		cooldown := time.Duration(l.CooldownSeconds) * time.Second
+		if wait := usage.LastToolCall.Add(cooldown).Sub(now); wait > 0 {
+			return violation(ToolLimitCooldown, tool, float64(l.CooldownSeconds), now.Sub(*usage.LastToolCall).Seconds(), wait)
+		}
+	}
+	if l.CostPerCallUSD > 0 {
+		if p.DailyBudgetUSD > 0 && usage.CostToday+l.CostPerCallUSD > p.DailyBudgetUSD {
+			return violation(ToolLimitDailyBudget, tool, p.DailyBudgetUSD, usage.CostToday, nextDay.Sub(now))
+		}
+		if p.MonthlyBudgetUSD > 0 && usage.CostMonth+l.CostPerCallUSD > p.MonthlyBudgetUSD {
+			return violation(ToolLimitMonthlyBudget, tool, p.MonthlyBudgetUSD, usage.CostMonth, nextMonth.Sub(now))
+		}
+	}
+	return nil
+}
+
+func violation(reason, tool string, limit, used float64, retryAfter time.Duration) *ToolLimitViolation {
+	return &ToolLimitViolation{
+		Reason:            reason,
+		Tool:              tool,
+		Limit:             limit,
+		Used:              used,
+		RetryAfterSeconds: int(math.Ceil(retryAfter.Seconds())),
+	}
+}
@@ -0,0 +1,52 @@
+package mcp
+
+import (
+	"testing"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+func TestEvaluateToolLimits(t *testing.T) {
+	now := time.Date(2026, 3, 31, 23, 0, 0, 0, time.UTC)
+	lastCall := now.Add(-20 * time.Second)
+	policy := &domain.MCPPolicies{
+		MaxInvocationsPerMinute: 10,
+		MaxInvocationsPerDay:    100,
+		DailyBudgetUSD:          1,
+		MonthlyBudgetUSD:        5,
+		ToolLimits: []domain.MCPToolLimit{
+			{Tool: "tavily__search", CooldownSeconds: 30, CostPerCallUSD: 0.25},
+			{Tool: "local__calculator"},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		tool       string
+		usage      domain.MCPToolUsage
+		reason     string
+		retryAfter int
+	}{
+		{"under limits", "tavily__search", domain.MCPToolUsage{LastMinute: 3, Today: 20, CostToday: 0.5}, "", 0},
+		{"per minute", "local__calculator", domain.MCPToolUsage{LastMinute: 10}, ToolLimitPerMinute, 60},
+		{"per day", "local__calculator", domain.MCPToolUsage{Today: 100}, ToolLimitPerDay, 3600},
+		{"cooldown", "tavily__search", domain.MCPToolUsage{LastToolCall: &lastCall}, ToolLimitCooldown, 10},
+		{"daily budget", "tavily__search", domain.MCPToolUsage{CostToday: 0.8}, ToolLimitDailyBudget, 3600},
+		{"monthly budget", "tavily__search", domain.MCPToolUsage{CostMonth: 4.9}, ToolLimitMonthlyBudget, 3600},
+		{"budget skips free tools", "local__calculator", domain.MCPToolUsage{CostToday: 5, CostMonth: 5}, "", 0},
+		{"unlisted tool", "github__search", domain.MCPToolUsage{LastToolCall: &lastCall, CostToday: 5}, "", 0},
+	}
+	for _, tt := range tests {
+		v := evaluateToolLimits(policy, tt.tool, &tt.usage, now)
+		if tt.reason == "" {
+			if v != nil {
+				t.Errorf("%s: unexpected violation %+v", tt.name, v)
+			}
+			continue
+		}
+		if v == nil || v.Reason != tt.reason || v.RetryAfterSeconds != tt.retryAfter {
+			t.Errorf("%s: got %+v, want reason %s retrying after %ds", tt.name, v, tt.reason, tt.retryAfter)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package mcp
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"modelgate/internal/domain"
+)
+
+func TestNetworkCheck(t *testing.T) {
+	s := &MCPServer{}
+	key := &domain.APIKey{ID: "k1"}
+	r := httptest.NewRequest("POST", "/mcp", nil)
+
+	if err := s.checkNetwork(r, key); err != nil {
+		t.Fatalf("expected no check to allow the request, got %v", err)
+	}
+
+	s.SetNetworkCheck(func(r *http.Request, key *domain.APIKey) string {
+		return "client IP is not in the API key's allowlist"
+	})
+	var denied *networkDeniedError
+	if err := s.checkNetwork(r, key); !errors.As(err, &denied) {
+		t.Fatalf("expected a network denial, got %v", err)
+	}
+}
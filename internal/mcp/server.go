@@ -6,6 +6,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -16,6 +17,7 @@ import (
 
 	"modelgate/internal/domain"
 	"modelgate/internal/storage/postgres"
+	"modelgate/internal/toolapproval"
 
 	"github.com/google/uuid"
 )
@@ -29,6 +31,12 @@ type MCPServer struct {
 	store   *postgres.Store
 	mu      sync.RWMutex
 
+	// Queues denied tools for admin review (nil when disabled)
+	approvals *toolapproval.Service
+
+	// Applies API key network policies (nil when not set)
+	networkCheck NetworkCheck
+
 	// Server configuration
 	serverInfo   ServerInfo
 	capabilities ServerCapabilities
@@ -109,6 +117,10 @@ type ToolDefinition struct {
 	Name        string                 `json:"name"`
 	Description string                 `json:"description,omitempty"`
 	InputSchema map[string]interface{} `json:"inputSchema"`
+
+	// Set on tools the role can't call, listed when its MCP policy asks for them
+	Disabled       bool   `json:"disabled,omitempty"`
+	DisabledReason string `json:"disabledReason,omitempty"`
 }
 
 type ListToolsResult struct {
@@ -134,6 +146,7 @@ type ContentBlock struct {
 type AuthenticatedClient struct {
 	TenantSlug  string
 	RoleID      string
+	RoleIDs     []string // Roles whose tool permissions apply; just RoleID when empty
 	APIKeyID    string
 	ClientInfo  ClientInfo
 	ConnectedAt time.Time
@@ -169,12 +182,40 @@ func (s *MCPServer) RegisterTenantStore(tenantSlug string, store *postgres.Tenan
 // HTTP/SSE TRANSPORT
 // ============================================
 
+// NetworkCheck applies an API key's network policy to a request. It returns
+// an empty string when the request may proceed, otherwise the reason it was
+// denied.
+type NetworkCheck func(r *http.Request, key *domain.APIKey) string
+
+// networkDeniedError is returned by authenticateRequest for a valid API key
+// used from a network its policy doesn't allow
+type networkDeniedError struct {
+	reason string
+}
+
+func (e *networkDeniedError) Error() string {
+	return "request not permitted from this network: " + e.reason
+}
+
+// SetNetworkCheck applies check to the API key of every request, as the
+// OpenAI API does
+func (s *MCPServer) SetNetworkCheck(check NetworkCheck) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.networkCheck = check
+}
+
 // ServeHTTP handles MCP requests over HTTP/SSE
 // Authentication is via Bearer token (tenant API key)
 func (s *MCPServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Authenticate the request
 	client, err := s.authenticateRequest(r)
 	if err != nil {
+		var denied *networkDeniedError
+		if errors.As(err, &denied) {
+			http.Error(w, `{"error": "access_denied", "message": "`+err.Error()+`"}`, http.StatusForbidden)
+			return
+		}
 		http.Error(w, `{"error": "unauthorized", "message": "`+err.Error()+`"}`, http.StatusUnauthorized)
 		return
 	}
@@ -211,27 +252,42 @@ func (s *MCPServer) authenticateRequest(r *http.Request) (*AuthenticatedClient,
 	if err != nil {
 		return nil, fmt.Errorf("invalid API key: %w", err)
 	}
+	if apiKeyObj.ExpiresAt != nil && apiKeyObj.ExpiresAt.Before(time.Now()) {
+		return nil, fmt.Errorf("API key expired")
+	}
+	if err := s.checkNetwork(r, apiKeyObj); err != nil {
+		return nil, err
+	}
 
 	// Single tenant mode - always use "default"
 	tenantSlug := "default"
 
 	// Ensure tenant store is registered for MCP operations
 	s.mu.RLock()
-	_, exists := s.stores[tenantSlug]
+	store, exists := s.stores[tenantSlug]
 	s.mu.RUnlock()
 
 	if !exists {
-		if store, err := s.store.GetTenantStore(tenantSlug); err == nil {
+		if store, err = s.store.GetTenantStore(tenantSlug); err == nil {
 			s.RegisterTenantStore(tenantSlug, store)
 		}
 	}
 
-	return &AuthenticatedClient{
-		TenantSlug:  tenantSlug,
-		RoleID:      apiKeyObj.RoleID,
-		APIKeyID:    apiKeyObj.ID,
-		ConnectedAt: time.Now(),
-	}, nil
+	return NewClient(r.Context(), store, tenantSlug, apiKeyObj), nil
+}
+
+// checkNetwork applies the network check, if one is set, to an API key
+func (s *MCPServer) checkNetwork(r *http.Request, key *domain.APIKey) error {
+	s.mu.RLock()
+	check := s.networkCheck
+	s.mu.RUnlock()
+	if check == nil {
+		return nil
+	}
+	if reason := check(r, key); reason != "" {
+		return &networkDeniedError{reason: reason}
+	}
+	return nil
 }
 
 // hashAPIKey creates a SHA-256 hash of the API key
@@ -374,9 +430,10 @@ func (s *MCPServer) handleInitialize(ctx context.Context, client *AuthenticatedC
 }
 
 // handleListTools returns all available tools for the client
-// Only tools with ALLOW visibility are returned in tools/list
+// Only tools with ALLOW visibility in one of the client's roles are returned in tools/list
 // Tools with SEARCH visibility are hidden but can be discovered via tool_search
-// Tools with DENY visibility are completely hidden
+// Tools with DENY visibility are hidden, or listed as disabled when a role's
+// MCP policy sets list_denied_tools
 func (s *MCPServer) handleListTools(ctx context.Context, client *AuthenticatedClient) (*ListToolsResult, error) {
 	tools := []ToolDefinition{}
 
@@ -415,23 +472,19 @@ func (s *MCPServer) handleListTools(ctx context.Context, client *AuthenticatedCl
 		// List tools from all connected MCP servers
 		mcpTools, err := store.ListAllMCPTools(ctx)
 		if err == nil {
+			perms, err := store.ListMCPToolVisibilities(ctx, client.roles())
+			if err != nil {
+				slog.Warn("Failed to load MCP tool permissions", "role_id", client.RoleID, "error", err)
+				perms = nil // Everything is denied
+			}
+			showDenied := listDeniedTools(ctx, store, client)
 			for _, tool := range mcpTools {
 				if tool.IsDeprecated {
 					continue
 				}
-
-				// Check visibility - only ALLOW tools appear in tools/list
-				visibility := store.GetMCPToolVisibility(ctx, client.RoleID, tool.ID)
-				if visibility != domain.MCPVisibilityAllow {
-					continue // SEARCH and DENY tools are not listed
+				if def, ok := listedTool(tool, perms[tool.ID], showDenied); ok {
+					tools = append(tools, def)
 				}
-
-				// Convert to MCP tool definition with sanitized name
-				tools = append(tools, ToolDefinition{
-					Name:        SanitizeToolName(tool.ServerName, tool.Name),
-					Description: tool.Description,
-					InputSchema: tool.InputSchema,
-				})
 			}
 		}
 	}
@@ -439,6 +492,18 @@ func (s *MCPServer) handleListTools(ctx context.Context, client *AuthenticatedCl
 	return &ListToolsResult{Tools: tools}, nil
 }
 
+// ListTools returns the tools a client sees in tools/list, for callers
+// outside an MCP session such as assistant runs
+func (s *MCPServer) ListTools(ctx context.Context, client *AuthenticatedClient) (*ListToolsResult, error) {
+	return s.handleListTools(ctx, client)
+}
+
+// CallTool runs a tool as tools/call does, with the client's visibility,
+// invocation limits and execution log
+func (s *MCPServer) CallTool(ctx context.Context, client *AuthenticatedClient, name string, args map[string]any) (*CallToolResult, error) {
+	return s.handleCallTool(ctx, client, CallToolParams{Name: name, Arguments: args})
+}
+
 // handleCallTool executes a tool
 func (s *MCPServer) handleCallTool(ctx context.Context, client *AuthenticatedClient, params CallToolParams) (*CallToolResult, error) {
 	startTime := time.Now()
@@ -509,15 +574,44 @@ func (s *MCPServer) handleCallTool(ctx context.Context, client *AuthenticatedCli
 	}
 
 	// Check visibility - DENY tools cannot be called
-	visibility := store.GetMCPToolVisibility(ctx, client.RoleID, tool.ID)
+	visibility := toolVisibility(ctx, store, client, tool.ID)
 	if visibility == domain.MCPVisibilityDeny {
+		// Tools nobody has decided on yet are queued for an admin
+		denial := "Tool access denied by policy"
+		if approval := s.requestToolApproval(ctx, store, client, targetServer, tool); approval != nil {
+			switch approval.Status {
+			case domain.MCPApprovalPending:
+				return nil, approvalPendingError(params.Name, approval)
+			case domain.MCPApprovalDenied:
+				if approval.DecisionReason != "" {
+					denial += ": " + approval.DecisionReason
+				}
+			}
+		}
 		return &CallToolResult{
-			Content: []ContentBlock{{Type: "text", Text: "Tool access denied by policy"}},
+			Content: []ContentBlock{{Type: "text", Text: denial}},
 			IsError: true,
 		}, nil
 	}
 	// ALLOW and SEARCH visibility tools can be called
 
+	// Enforce the role's invocation limits and tool budgets
+	cost, violation := s.checkToolLimits(ctx, store, client, tool.ID, params.Name, startTime)
+	if violation != nil {
+		store.LogMCPToolExecution(ctx, &domain.MCPToolExecution{
+			ID:           uuid.New().String(),
+			ServerID:     targetServer.ID,
+			ToolID:       tool.ID,
+			RoleID:       client.RoleID,
+			APIKeyID:     client.APIKeyID,
+			InputParams:  params.Arguments,
+			Status:       domain.MCPExecBlocked,
+			ErrorMessage: violation.Message(),
+			StartedAt:    startTime,
+		})
+		return nil, violation.rpcError()
+	}
+
 	// Execute via gateway
 	result, err := s.gateway.ExecuteTool(ctx, targetServer, toolName, params.Arguments)
 
@@ -539,6 +633,7 @@ func (s *MCPServer) handleCallTool(ctx context.Context, client *AuthenticatedCli
 		OutputResult: result,
 		Status:       execStatus,
 		ErrorMessage: errMsg,
+		CostUSD:      cost,
 		StartedAt:    startTime,
 		DurationMs:   int(time.Since(startTime).Milliseconds()),
 	})
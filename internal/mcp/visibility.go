@@ -0,0 +1,111 @@
+package mcp
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"modelgate/internal/domain"
+	"modelgate/internal/storage/postgres"
+)
+
+// deniedToolReason describes a listed tool the client can't use when the
+// admin who denied it gave no reason
+const deniedToolReason = "not permitted for your role"
+
+// NewClient returns the client of an API key. Its tool permissions are those
+// of the key's role and of its group's roles; RoleID, which invocation limits
+// and approvals apply to, is the key's own role or else the group's first.
+func NewClient(ctx context.Context, store *postgres.TenantStore, tenantSlug string, key *domain.APIKey) *AuthenticatedClient {
+	client := &AuthenticatedClient{
+		TenantSlug:  tenantSlug,
+		RoleID:      key.RoleID,
+		APIKeyID:    key.ID,
+		ConnectedAt: time.Now(),
+	}
+	if key.RoleID != "" {
+		client.RoleIDs = append(client.RoleIDs, key.RoleID)
+	}
+	if key.GroupID != "" && store != nil {
+		roles, err := store.GetGroupRoles(ctx, key.GroupID)
+		if err != nil {
+			slog.Warn("Failed to load group roles for MCP client", "group_id", key.GroupID, "error", err)
+		}
+		for _, role := range roles {
+			client.RoleIDs = append(client.RoleIDs, role.ID)
+		}
+	}
+	if client.RoleID == "" && len(client.RoleIDs) > 0 {
+		client.RoleID = client.RoleIDs[0]
+	}
+	return client
+}
+
+// roles returns the roles whose permissions apply to the client
+func (c *AuthenticatedClient) roles() []string {
+	if len(c.RoleIDs) == 0 && c.RoleID != "" {
+		return []string{c.RoleID}
+	}
+	return c.RoleIDs
+}
+
+// toolVisibility returns the most permissive visibility of a tool among the
+// client's roles
+func toolVisibility(ctx context.Context, store *postgres.TenantStore, client *AuthenticatedClient, toolID string) domain.MCPToolVisibility {
+	best := domain.MCPVisibilityDeny
+	for _, roleID := range client.roles() {
+		switch store.GetMCPToolVisibility(ctx, roleID, toolID) {
+		case domain.MCPVisibilityAllow:
+			return domain.MCPVisibilityAllow
+		case domain.MCPVisibilitySearch:
+			best = domain.MCPVisibilitySearch
+		}
+	}
+	return best
+}
+
+// listDeniedTools reports whether any of the client's roles lists the tools
+// it can't use instead of hiding them
+func listDeniedTools(ctx context.Context, store *postgres.TenantStore, client *AuthenticatedClient) bool {
+	for _, roleID := range client.roles() {
+		policy, err := store.GetRolePolicy(ctx, roleID)
+		if err != nil {
+			slog.Warn("Failed to load role policy for MCP tool listing", "role_id", roleID, "error", err)
+			continue
+		}
+		if policy != nil && policy.MCPPolicies.ListDeniedTools {
+			return true
+		}
+	}
+	return false
+}
+
+// listedTool returns the tools/list entry of a tool given the client's best
+// permission for it (nil when it has none). ALLOW tools are listed; SEARCH
+// tools are left to tool_search; DENY tools are hidden unless showDenied,
+// when they are listed as disabled with the reason they were denied.
+func listedTool(tool *domain.MCPTool, perm *domain.MCPToolPermission, showDenied bool) (ToolDefinition, bool) {
+	def := ToolDefinition{
+		Name:        SanitizeToolName(tool.ServerName, tool.Name),
+		Description: tool.Description,
+		InputSchema: tool.InputSchema,
+	}
+	visibility := domain.MCPVisibilityDeny
+	if perm != nil {
+		visibility = perm.Visibility
+	}
+	switch {
+	case visibility == domain.MCPVisibilityAllow:
+		return def, true
+	case visibility == domain.MCPVisibilityDeny && showDenied:
+		def.Disabled = true
+		def.DisabledReason = deniedToolReason
+		if perm != nil && perm.DecisionReason != "" {
+			def.DisabledReason = perm.DecisionReason
+		}
+		// Clients that ignore the flag still show the reason
+		def.Description = "[Disabled: " + def.DisabledReason + "] " + def.Description
+		return def, true
+	}
+	return ToolDefinition{}, false
+}
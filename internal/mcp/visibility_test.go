@@ -0,0 +1,56 @@
+package mcp
+
+import (
+	"strings"
+	"testing"
+
+	"modelgate/internal/domain"
+)
+
+func TestListedTool(t *testing.T) {
+	tool := &domain.MCPTool{ID: "t1", ServerName: "GitHub", Name: "create_issue", Description: "Open an issue"}
+	perm := func(v domain.MCPToolVisibility, reason string) *domain.MCPToolPermission {
+		return &domain.MCPToolPermission{ToolID: "t1", Visibility: v, DecisionReason: reason}
+	}
+
+	tests := []struct {
+		name       string
+		perm       *domain.MCPToolPermission
+		showDenied bool
+		listed     bool
+		reason     string
+	}{
+		{"allowed", perm(domain.MCPVisibilityAllow, ""), false, true, ""},
+		{"search only", perm(domain.MCPVisibilitySearch, ""), true, false, ""},
+		{"denied and hidden", perm(domain.MCPVisibilityDeny, "Writes are reviewed"), false, false, ""},
+		{"denied and listed", perm(domain.MCPVisibilityDeny, "Writes are reviewed"), true, true, "Writes are reviewed"},
+		{"never decided", nil, true, true, deniedToolReason},
+	}
+	for _, tt := range tests {
+		def, ok := listedTool(tool, tt.perm, tt.showDenied)
+		if ok != tt.listed {
+			t.Errorf("%s: listed = %v, want %v", tt.name, ok, tt.listed)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if def.Disabled != (tt.reason != "") || def.DisabledReason != tt.reason {
+			t.Errorf("%s: got disabled %v (%q), want reason %q", tt.name, def.Disabled, def.DisabledReason, tt.reason)
+		}
+		if def.Disabled && !strings.Contains(def.Description, tt.reason) {
+			t.Errorf("%s: expected the reason in the description, got %q", tt.name, def.Description)
+		}
+	}
+}
+
+func TestClientRoles(t *testing.T) {
+	client := &AuthenticatedClient{RoleID: "r1"}
+	if roles := client.roles(); len(roles) != 1 || roles[0] != "r1" {
+		t.Fatalf("expected the key's role, got %v", roles)
+	}
+	client.RoleIDs = []string{"r1", "g1", "g2"}
+	if roles := client.roles(); len(roles) != 3 {
+		t.Fatalf("expected the group's roles too, got %v", roles)
+	}
+}
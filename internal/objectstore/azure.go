@@ -0,0 +1,182 @@
+package objectstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"modelgate/internal/config"
+)
+
+// azureAPIVersion is the Blob service REST version used for requests
+const azureAPIVersion = "2021-08-06"
+
+// azureStore writes block blobs to an Azure Storage container using Shared Key auth
+type azureStore struct {
+	httpClient *http.Client
+	endpoint   string // e.g. https://<account>.blob.core.windows.net
+	account    string
+	key        []byte
+	container  string
+	prefix     string
+}
+
+func newAzureStore(cfg config.ObjectStoreConfig) (*azureStore, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("azure object store requires a container (bucket)")
+	}
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, fmt.Errorf("azure object store requires the storage account name (access_key_id) and key (secret_access_key)")
+	}
+	key, err := base64.StdEncoding.DecodeString(cfg.SecretAccessKey)
+	if err != nil {
+		return nil, fmt.Errorf("azure account key is not valid base64: %w", err)
+	}
+
+	endpoint := strings.TrimSuffix(cfg.Endpoint, "/")
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://%s.blob.core.windows.net", cfg.AccessKeyID)
+	}
+	return &azureStore{
+		httpClient: &http.Client{Timeout: 5 * time.Minute},
+		endpoint:   endpoint,
+		account:    cfg.AccessKeyID,
+		key:        key,
+		container:  cfg.Bucket,
+		prefix:     cfg.Prefix,
+	}, nil
+}
+
+func (s *azureStore) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	return s.PutStream(ctx, key, bytes.NewReader(data), int64(len(data)), contentType)
+}
+
+// PutStream uploads a block blob in a single request, so objects are limited
+// to the Blob service's single-put size (5000 MiB)
+func (s *azureStore) PutStream(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	req, blobName, err := s.newRequest(ctx, http.MethodPut, key, body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("x-ms-blob-type", "BlockBlob")
+
+	resp, err := s.do(req)
+	if err != nil {
+		return fmt.Errorf("put azure://%s/%s: %w", s.container, blobName, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("put azure://%s/%s: %w", s.container, blobName, azureError(resp))
+	}
+	return nil
+}
+
+func (s *azureStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, blobName, err := s.newRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("get azure://%s/%s: %w", s.container, blobName, err)
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, ErrNotFound
+	default:
+		defer resp.Body.Close()
+		return nil, fmt.Errorf("get azure://%s/%s: %w", s.container, blobName, azureError(resp))
+	}
+}
+
+func (s *azureStore) Delete(ctx context.Context, key string) error {
+	req, blobName, err := s.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		return fmt.Errorf("delete azure://%s/%s: %w", s.container, blobName, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("delete azure://%s/%s: %w", s.container, blobName, azureError(resp))
+	}
+	return nil
+}
+
+// newRequest builds a request for the blob holding key
+func (s *azureStore) newRequest(ctx context.Context, method, key string, body io.Reader) (*http.Request, string, error) {
+	blobName := path.Join(s.prefix, key)
+	segments := strings.Split(blobName, "/")
+	for i, seg := range segments {
+		segments[i] = url.PathEscape(seg)
+	}
+	target := s.endpoint + "/" + url.PathEscape(s.container) + "/" + strings.Join(segments, "/")
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	return req, blobName, err
+}
+
+// do signs and sends a request
+func (s *azureStore) do(req *http.Request) (*http.Response, error) {
+	req.Header.Set("x-ms-date", time.Now().UTC().Format(http.TimeFormat))
+	req.Header.Set("x-ms-version", azureAPIVersion)
+	req.Header.Set("Authorization", "SharedKey "+s.account+":"+s.sign(req))
+	return s.httpClient.Do(req)
+}
+
+func azureError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	return fmt.Errorf("status %d: %s", resp.StatusCode, string(body))
+}
+
+// sign computes the Shared Key signature for a request without query parameters
+func (s *azureStore) sign(req *http.Request) string {
+	contentLength := ""
+	if req.ContentLength > 0 {
+		contentLength = strconv.FormatInt(req.ContentLength, 10)
+	}
+
+	var msHeaders []string
+	for name := range req.Header {
+		if lower := strings.ToLower(name); strings.HasPrefix(lower, "x-ms-") {
+			msHeaders = append(msHeaders, lower+":"+strings.TrimSpace(req.Header.Get(name)))
+		}
+	}
+	sort.Strings(msHeaders)
+
+	stringToSign := strings.Join([]string{
+		req.Method,
+		req.Header.Get("Content-Encoding"),
+		req.Header.Get("Content-Language"),
+		contentLength,
+		req.Header.Get("Content-MD5"),
+		req.Header.Get("Content-Type"),
+		"", // Date (x-ms-date is used instead)
+		req.Header.Get("If-Modified-Since"),
+		req.Header.Get("If-Match"),
+		req.Header.Get("If-None-Match"),
+		req.Header.Get("If-Unmodified-Since"),
+		req.Header.Get("Range"),
+	}, "\n") + "\n" + strings.Join(msHeaders, "\n") + "\n/" + s.account + req.URL.EscapedPath()
+
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(stringToSign))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,198 @@
+// Package objectstore keeps archives, exports and files in object storage (S3, GCS, Azure Blob or local disk).
+package objectstore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+
+	"modelgate/internal/config"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// gcsEndpoint is the S3-compatible XML API of Google Cloud Storage (used with HMAC keys)
+const gcsEndpoint = "https://storage.googleapis.com"
+
+// ErrNotFound is returned by Get when the object does not exist
+var ErrNotFound = errors.New("object not found")
+
+// Store keeps objects under a configured bucket and prefix
+type Store interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	// PutStream writes size bytes read from body, for objects too large to hold in memory
+	PutStream(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Get opens an object for reading. The caller must close the reader.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes an object; deleting a missing object is not an error
+	Delete(ctx context.Context, key string) error
+}
+
+// New creates a store for the configured backend ("s3", "gcs", "azure" or "local")
+func New(ctx context.Context, cfg config.ObjectStoreConfig) (Store, error) {
+	switch cfg.Type {
+	case "s3":
+		return newS3Store(ctx, cfg, false)
+	case "gcs":
+		return newS3Store(ctx, cfg, true)
+	case "azure":
+		return newAzureStore(cfg)
+	case "local":
+		if cfg.LocalDir == "" {
+			return nil, fmt.Errorf("local object store requires local_dir")
+		}
+		return &localStore{dir: cfg.LocalDir, prefix: cfg.Prefix}, nil
+	default:
+		return nil, fmt.Errorf("unsupported object store type: %q", cfg.Type)
+	}
+}
+
+type s3Store struct {
+	client *s3.Client
+	bucket string
+	prefix string
+}
+
+func newS3Store(ctx context.Context, cfg config.ObjectStoreConfig, gcs bool) (*s3Store, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("object store requires a bucket")
+	}
+
+	region := cfg.Region
+	endpoint := cfg.Endpoint
+	if gcs {
+		if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+			return nil, fmt.Errorf("gcs object store requires HMAC access_key_id and secret_access_key")
+		}
+		if endpoint == "" {
+			endpoint = gcsEndpoint
+		}
+		if region == "" {
+			region = "auto"
+		}
+	}
+
+	opts := []func(*awsconfig.LoadOptions) error{awsconfig.WithRegion(region)}
+	if cfg.AccessKeyID != "" {
+		opts = append(opts, awsconfig.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(cfg.AccessKeyID, cfg.SecretAccessKey, "")))
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("load AWS config: %w", err)
+	}
+
+	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+			o.UsePathStyle = true
+		}
+		if gcs {
+			// GCS rejects the flexible checksum trailers the SDK adds by default
+			o.RequestChecksumCalculation = aws.RequestChecksumCalculationWhenRequired
+		}
+	})
+	return &s3Store{client: client, bucket: cfg.Bucket, prefix: cfg.Prefix}, nil
+}
+
+func (s *s3Store) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	return s.PutStream(ctx, key, bytes.NewReader(data), int64(len(data)), contentType)
+}
+
+func (s *s3Store) PutStream(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(s.bucket),
+		Key:           aws.String(path.Join(s.prefix, key)),
+		Body:          body,
+		ContentLength: aws.Int64(size),
+		ContentType:   aws.String(contentType),
+	})
+	if err != nil {
+		return fmt.Errorf("put s3://%s/%s: %w", s.bucket, path.Join(s.prefix, key), err)
+	}
+	return nil
+}
+
+func (s *s3Store) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(path.Join(s.prefix, key)),
+	})
+	if err != nil {
+		var noKey *types.NoSuchKey
+		if errors.As(err, &noKey) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("get s3://%s/%s: %w", s.bucket, path.Join(s.prefix, key), err)
+	}
+	return out.Body, nil
+}
+
+func (s *s3Store) Delete(ctx context.Context, key string) error {
+	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(path.Join(s.prefix, key)),
+	})
+	if err != nil {
+		return fmt.Errorf("delete s3://%s/%s: %w", s.bucket, path.Join(s.prefix, key), err)
+	}
+	return nil
+}
+
+// localStore writes objects to a directory, for development and on-prem archiving
+type localStore struct {
+	dir    string
+	prefix string
+}
+
+func (s *localStore) Put(_ context.Context, key string, data []byte, _ string) error {
+	target := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(target), 0o750); err != nil {
+		return fmt.Errorf("create archive dir: %w", err)
+	}
+	return os.WriteFile(target, data, 0o640)
+}
+
+func (s *localStore) PutStream(_ context.Context, key string, body io.Reader, _ int64, _ string) error {
+	target := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(target), 0o750); err != nil {
+		return fmt.Errorf("create archive dir: %w", err)
+	}
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o640)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func (s *localStore) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	f, err := os.Open(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+func (s *localStore) Delete(_ context.Context, key string) error {
+	if err := os.Remove(s.path(key)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *localStore) path(key string) string {
+	return filepath.Join(s.dir, filepath.FromSlash(path.Join(s.prefix, key)))
+}
@@ -0,0 +1,240 @@
+// Package ollama manages the models installed on a self-hosted Ollama backend
+// and keeps them in sync with the gateway's available models.
+package ollama
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"modelgate/internal/domain"
+	"modelgate/internal/provider"
+)
+
+// Pull statuses
+const (
+	PullStatusPulling   = "pulling"
+	PullStatusCompleted = "completed"
+	PullStatusFailed    = "failed"
+)
+
+// ErrPullInProgress is returned when the model is already being pulled
+var ErrPullInProgress = errors.New("model is already being pulled")
+
+// Store is the persistence used by the service (implemented by postgres.Store)
+type Store interface {
+	GetProviderConfig(ctx context.Context, provider domain.Provider) (*domain.ProviderConfig, error)
+	DeleteProviderModels(ctx context.Context, provider string) error
+	SaveAvailableModels(ctx context.Context, provider string, models []domain.ModelInfo) error
+}
+
+// Model is an installed model with its resource usage
+type Model struct {
+	Name              string
+	SizeBytes         int64
+	Digest            string
+	Family            string
+	ParameterSize     string
+	QuantizationLevel string
+	ModifiedAt        time.Time
+	Loaded            bool       // Currently held in memory
+	VRAMBytes         int64      // GPU memory used while loaded
+	ExpiresAt         *time.Time // When a loaded model will be unloaded
+}
+
+// Pull tracks the progress of a model download
+type Pull struct {
+	Model          string
+	Status         string
+	Detail         string // Latest status line reported by Ollama
+	CompletedBytes int64
+	TotalBytes     int64
+	Error          string
+	StartedAt      time.Time
+	FinishedAt     *time.Time
+}
+
+// Service lists, pulls and deletes Ollama models
+type Service struct {
+	store Store
+
+	mu    sync.Mutex
+	pulls map[string]*Pull
+}
+
+// NewService creates a new Ollama management service
+func NewService(store Store) *Service {
+	return &Service{store: store, pulls: make(map[string]*Pull)}
+}
+
+// client builds a client from the stored Ollama provider configuration
+func (s *Service) client(ctx context.Context) (*provider.OllamaClient, error) {
+	cfg, err := s.store.GetProviderConfig(ctx, domain.ProviderOllama)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get provider config: %w", err)
+	}
+	if cfg == nil || !cfg.Enabled {
+		return nil, fmt.Errorf("provider ollama is not enabled")
+	}
+	connSettings := cfg.ConnectionSettings
+	if connSettings.MaxConnections == 0 {
+		connSettings = domain.DefaultConnectionSettings()
+	}
+	return provider.NewOllamaClient(cfg.BaseURL, connSettings)
+}
+
+// Models returns the installed models, marking those currently loaded
+func (s *Service) Models(ctx context.Context) ([]Model, error) {
+	client, err := s.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	local, err := client.ListLocalModels(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list ollama models: %w", err)
+	}
+	running, err := client.RunningModels(ctx)
+	if err != nil {
+		// Older Ollama versions have no /api/ps; report models as unloaded
+		slog.Warn("Failed to list running Ollama models", "error", err)
+	}
+	loaded := make(map[string]provider.OllamaRunningModel, len(running))
+	for _, m := range running {
+		loaded[m.Name] = m
+	}
+
+	models := make([]Model, 0, len(local))
+	for _, m := range local {
+		model := Model{
+			Name:              m.Name,
+			SizeBytes:         m.Size,
+			Digest:            m.Digest,
+			Family:            m.Details.Family,
+			ParameterSize:     m.Details.ParameterSize,
+			QuantizationLevel: m.Details.QuantizationLevel,
+			ModifiedAt:        m.ModifiedAt,
+		}
+		if r, ok := loaded[m.Name]; ok {
+			model.Loaded = true
+			model.VRAMBytes = r.SizeVRAM
+			if !r.ExpiresAt.IsZero() {
+				expires := r.ExpiresAt
+				model.ExpiresAt = &expires
+			}
+		}
+		models = append(models, model)
+	}
+	sort.Slice(models, func(i, j int) bool { return models[i].Name < models[j].Name })
+	return models, nil
+}
+
+// Pull starts downloading a model in the background and returns its progress
+// record. The available models are re-synced once the pull succeeds.
+func (s *Service) Pull(ctx context.Context, name string) (*Pull, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("model name is required")
+	}
+	client, err := s.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	if p, ok := s.pulls[name]; ok && p.Status == PullStatusPulling {
+		s.mu.Unlock()
+		return nil, ErrPullInProgress
+	}
+	pull := &Pull{Model: name, Status: PullStatusPulling, StartedAt: time.Now()}
+	s.pulls[name] = pull
+	snapshot := *pull
+	s.mu.Unlock()
+
+	go s.runPull(context.WithoutCancel(ctx), client, pull)
+	return &snapshot, nil
+}
+
+func (s *Service) runPull(ctx context.Context, client *provider.OllamaClient, pull *Pull) {
+	err := client.PullModel(ctx, pull.Model, func(p provider.OllamaPullProgress) {
+		s.mu.Lock()
+		pull.Detail = p.Status
+		if p.Total > 0 {
+			pull.TotalBytes = p.Total
+			pull.CompletedBytes = p.Completed
+		}
+		s.mu.Unlock()
+	})
+
+	now := time.Now()
+	s.mu.Lock()
+	pull.FinishedAt = &now
+	if err != nil {
+		pull.Status = PullStatusFailed
+		pull.Error = err.Error()
+	} else {
+		pull.Status = PullStatusCompleted
+	}
+	s.mu.Unlock()
+
+	if err != nil {
+		slog.Error("Ollama model pull failed", "model", pull.Model, "error", err)
+		return
+	}
+	slog.Info("Ollama model pulled", "model", pull.Model)
+	if _, err := s.Sync(ctx); err != nil {
+		slog.Error("Failed to sync Ollama models after pull", "model", pull.Model, "error", err)
+	}
+}
+
+// Pulls returns the pulls started since the gateway started, newest first
+func (s *Service) Pulls() []Pull {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Pull, 0, len(s.pulls))
+	for _, p := range s.pulls {
+		out = append(out, *p)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].StartedAt.After(out[j].StartedAt) })
+	return out
+}
+
+// Delete removes an installed model and re-syncs the available models
+func (s *Service) Delete(ctx context.Context, name string) error {
+	client, err := s.client(ctx)
+	if err != nil {
+		return err
+	}
+	if err := client.DeleteModel(ctx, name); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	delete(s.pulls, name)
+	s.mu.Unlock()
+	_, err = s.Sync(ctx)
+	return err
+}
+
+// Sync replaces the Ollama entries in available_models with the installed
+// models so they are served from /v1/models. It returns the number synced.
+func (s *Service) Sync(ctx context.Context) (int, error) {
+	client, err := s.client(ctx)
+	if err != nil {
+		return 0, err
+	}
+	models, err := client.ListModels(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("list ollama models: %w", err)
+	}
+	if err := s.store.DeleteProviderModels(ctx, string(domain.ProviderOllama)); err != nil {
+		return 0, fmt.Errorf("failed to delete existing models: %w", err)
+	}
+	if err := s.store.SaveAvailableModels(ctx, string(domain.ProviderOllama), models); err != nil {
+		return 0, fmt.Errorf("failed to save models to database: %w", err)
+	}
+	return len(models), nil
+}
@@ -0,0 +1,147 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+type fakeStore struct {
+	baseURL string
+
+	mu     sync.Mutex
+	saved  []domain.ModelInfo
+	synced int
+}
+
+func (f *fakeStore) GetProviderConfig(ctx context.Context, p domain.Provider) (*domain.ProviderConfig, error) {
+	return &domain.ProviderConfig{Provider: p, Enabled: true, BaseURL: f.baseURL}, nil
+}
+
+func (f *fakeStore) DeleteProviderModels(ctx context.Context, provider string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.saved = nil
+	return nil
+}
+
+func (f *fakeStore) SaveAvailableModels(ctx context.Context, provider string, models []domain.ModelInfo) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.saved = models
+	f.synced++
+	return nil
+}
+
+// fakeOllama serves the subset of the Ollama API used by the service
+func fakeOllama(t *testing.T) *httptest.Server {
+	var mu sync.Mutex
+	installed := map[string]int64{"llama3:8b": 4_700_000_000}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/tags", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		var models []map[string]any
+		for name, size := range installed {
+			models = append(models, map[string]any{
+				"name": name, "size": size, "digest": "sha256:abc",
+				"details": map[string]string{"family": "llama", "parameter_size": "8B", "quantization_level": "Q4_0"},
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"models": models})
+	})
+	mux.HandleFunc("GET /api/ps", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"models": []map[string]any{
+			{"name": "llama3:8b", "size": 5_000_000_000, "size_vram": 4_800_000_000, "expires_at": time.Now().Add(time.Minute)},
+		}})
+	})
+	mux.HandleFunc("POST /api/pull", func(w http.ResponseWriter, r *http.Request) {
+		var req struct{ Model string }
+		json.NewDecoder(r.Body).Decode(&req)
+		fmt.Fprintln(w, `{"status":"pulling manifest"}`)
+		fmt.Fprintln(w, `{"status":"downloading","digest":"sha256:def","total":100,"completed":100}`)
+		fmt.Fprintln(w, `{"status":"success"}`)
+		mu.Lock()
+		installed[req.Model] = 100
+		mu.Unlock()
+	})
+	mux.HandleFunc("DELETE /api/delete", func(w http.ResponseWriter, r *http.Request) {
+		var req struct{ Model string }
+		json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		defer mu.Unlock()
+		if _, ok := installed[req.Model]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(installed, req.Model)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestModelLifecycle(t *testing.T) {
+	ctx := context.Background()
+	store := &fakeStore{baseURL: fakeOllama(t).URL}
+	svc := NewService(store)
+
+	models, err := svc.Models(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(models) != 1 || !models[0].Loaded || models[0].VRAMBytes != 4_800_000_000 || models[0].QuantizationLevel != "Q4_0" {
+		t.Fatalf("unexpected models: %+v", models)
+	}
+
+	if _, err := svc.Pull(ctx, "qwen2:0.5b"); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		pulls := svc.Pulls()
+		if len(pulls) == 1 && pulls[0].Status == PullStatusCompleted {
+			if pulls[0].CompletedBytes != 100 {
+				t.Fatalf("expected progress to be tracked, got %+v", pulls[0])
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("pull did not complete: %+v", pulls)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// The pulled model is synced into available models
+	deadline = time.Now().Add(5 * time.Second)
+	for {
+		store.mu.Lock()
+		n := len(store.saved)
+		store.mu.Unlock()
+		if n == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected 2 synced models, got %d", n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := svc.Delete(ctx, "llama3:8b"); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.saved) != 1 || store.saved[0].ID != "ollama/qwen2:0.5b" {
+		t.Fatalf("expected only the pulled model after delete, got %+v", store.saved)
+	}
+	if err := svc.Delete(ctx, "llama3:8b"); err == nil {
+		t.Fatal("expected deleting a missing model to fail")
+	}
+}
@@ -0,0 +1,220 @@
+// Package passwords hashes dashboard user passwords and holds the rules they
+// must follow: length and complexity, expiry, reuse, and lockout after failed
+// logins. Hashes are bcrypt or argon2id; a hash made with another algorithm or
+// older parameters still verifies, and is replaced at the next login.
+package passwords
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"modelgate/internal/config"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Hashing algorithms
+const (
+	AlgorithmBcrypt   = "bcrypt"
+	AlgorithmArgon2id = "argon2id"
+)
+
+const (
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+var (
+	ErrInvalidCredentials     = errors.New("invalid credentials")
+	ErrPasswordExpired        = errors.New("password has expired and must be changed")
+	ErrPasswordChangeRequired = errors.New("password must be changed before signing in")
+	ErrPasswordReused         = errors.New("password was used recently; choose another")
+)
+
+// LockedError is returned for logins to an account locked after failed logins
+type LockedError struct {
+	Until time.Time
+}
+
+func (e *LockedError) Error() string {
+	wait := time.Until(e.Until).Round(time.Second)
+	return fmt.Sprintf("account is locked after too many failed logins; try again in %s", max(wait, time.Second))
+}
+
+// WeakError lists the rules a new password breaks
+type WeakError struct {
+	Reasons []string
+}
+
+func (e *WeakError) Error() string {
+	return "password " + strings.Join(e.Reasons, ", ")
+}
+
+// IsRefused reports whether err is a new password being refused by the
+// policy, as opposed to a failed login or a storage error
+func IsRefused(err error) bool {
+	var weak *WeakError
+	return errors.As(err, &weak) || errors.Is(err, ErrPasswordReused)
+}
+
+// Policy hashes passwords and enforces [passwords]
+type Policy struct {
+	cfg config.PasswordConfig
+
+	dummyOnce sync.Once
+	dummyHash string
+}
+
+// NewPolicy creates a password policy
+func NewPolicy(cfg config.PasswordConfig) *Policy {
+	return &Policy{cfg: cfg}
+}
+
+// Config returns the policy's settings
+func (p *Policy) Config() config.PasswordConfig {
+	return p.cfg
+}
+
+// Check returns a *WeakError if a new password breaks the length or
+// complexity rules, or contains one of the user's identifiers (such as the
+// local part of their email)
+func (p *Policy) Check(password string, identifiers ...string) error {
+	var reasons []string
+	if n := len([]rune(password)); n < p.cfg.MinLength {
+		reasons = append(reasons, fmt.Sprintf("must be at least %d characters", p.cfg.MinLength))
+	}
+	if p.cfg.MinCharClasses > 0 {
+		var lower, upper, digit, symbol bool
+		for _, r := range password {
+			switch {
+			case unicode.IsLower(r):
+				lower = true
+			case unicode.IsUpper(r):
+				upper = true
+			case unicode.IsDigit(r):
+				digit = true
+			default:
+				symbol = true
+			}
+		}
+		classes := 0
+		for _, has := range []bool{lower, upper, digit, symbol} {
+			if has {
+				classes++
+			}
+		}
+		if classes < p.cfg.MinCharClasses {
+			reasons = append(reasons, fmt.Sprintf("must mix at least %d of lowercase letters, uppercase letters, digits and symbols", p.cfg.MinCharClasses))
+		}
+	}
+	lowered := strings.ToLower(password)
+	for _, id := range identifiers {
+		id, _, _ = strings.Cut(strings.ToLower(id), "@")
+		if len(id) >= 3 && strings.Contains(lowered, id) {
+			reasons = append(reasons, "must not contain your name or email")
+			break
+		}
+	}
+	if len(reasons) > 0 {
+		return &WeakError{Reasons: reasons}
+	}
+	return nil
+}
+
+// Hash hashes a password with the configured algorithm
+func (p *Policy) Hash(password string) (string, error) {
+	if p.cfg.Algorithm != AlgorithmArgon2id {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), p.bcryptCost())
+		return string(hash), err
+	}
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, p.cfg.Argon2Iterations, p.cfg.Argon2Memory, p.cfg.Argon2Parallelism, argon2KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, p.cfg.Argon2Memory, p.cfg.Argon2Iterations,
+		p.cfg.Argon2Parallelism, base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Verify reports whether a password matches a hash, and whether the hash
+// should be replaced because it was made with another algorithm or weaker
+// parameters than those configured
+func (p *Policy) Verify(hash, password string) (ok, rehash bool) {
+	if strings.HasPrefix(hash, "$argon2id$") {
+		var version int
+		var memory, iterations uint32
+		var parallelism uint8
+		parts := strings.Split(hash, "$")
+		if len(parts) != 6 {
+			return false, false
+		}
+		if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+			return false, false
+		}
+		if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &iterations, &parallelism); err != nil {
+			return false, false
+		}
+		salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+		if err != nil {
+			return false, false
+		}
+		want, err := base64.RawStdEncoding.DecodeString(parts[5])
+		if err != nil || len(want) == 0 {
+			return false, false
+		}
+		got := argon2.IDKey([]byte(password), salt, iterations, memory, parallelism, uint32(len(want)))
+		if subtle.ConstantTimeCompare(got, want) != 1 {
+			return false, false
+		}
+		return true, p.cfg.Algorithm != AlgorithmArgon2id || memory != p.cfg.Argon2Memory ||
+			iterations != p.cfg.Argon2Iterations || parallelism != p.cfg.Argon2Parallelism
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil {
+		return false, false
+	}
+	cost, _ := bcrypt.Cost([]byte(hash))
+	return true, p.cfg.Algorithm == AlgorithmArgon2id || cost != p.bcryptCost()
+}
+
+// VerifyUnknown spends the time Verify would, for a login to a user that
+// doesn't exist, so it can't be told apart from a wrong password
+func (p *Policy) VerifyUnknown(password string) {
+	p.dummyOnce.Do(func() {
+		p.dummyHash, _ = p.Hash("unknown user")
+	})
+	p.Verify(p.dummyHash, password)
+}
+
+func (p *Policy) bcryptCost() int {
+	if p.cfg.BcryptCost == 0 {
+		return bcrypt.DefaultCost
+	}
+	return p.cfg.BcryptCost
+}
+
+// Expired reports whether a password changed at changedAt has passed its max age
+func (p *Policy) Expired(changedAt, now time.Time) bool {
+	return p.cfg.MaxAge > 0 && !changedAt.IsZero() && now.Sub(changedAt) >= p.cfg.MaxAge
+}
+
+// LockoutDuration returns how long an account is locked for its nth lockout
+// in a row: lockout_duration, doubled each time up to max_lockout_duration
+func (p *Policy) LockoutDuration(n int) time.Duration {
+	d := p.cfg.LockoutDuration
+	for i := 1; i < n && d < p.cfg.MaxLockoutDuration; i++ {
+		d *= 2
+	}
+	if p.cfg.MaxLockoutDuration > 0 {
+		d = min(d, p.cfg.MaxLockoutDuration)
+	}
+	return d
+}
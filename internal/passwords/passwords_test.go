@@ -0,0 +1,115 @@
+package passwords
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"modelgate/internal/config"
+)
+
+func testConfig() config.PasswordConfig {
+	cfg := config.Default().Passwords
+	cfg.BcryptCost = 4
+	cfg.Argon2Memory, cfg.Argon2Iterations, cfg.Argon2Parallelism = 64, 1, 1
+	return cfg
+}
+
+func TestHashAndVerify(t *testing.T) {
+	bcryptPolicy := NewPolicy(testConfig())
+	argonCfg := testConfig()
+	argonCfg.Algorithm = AlgorithmArgon2id
+	argonPolicy := NewPolicy(argonCfg)
+
+	hash, err := argonPolicy.Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$") {
+		t.Fatalf("unexpected argon2id hash %q", hash)
+	}
+	if ok, rehash := argonPolicy.Verify(hash, "correct horse"); !ok || rehash {
+		t.Fatalf("expected the password to verify without a rehash, got ok=%v rehash=%v", ok, rehash)
+	}
+	if ok, _ := argonPolicy.Verify(hash, "wrong horse"); ok {
+		t.Fatal("expected a wrong password to fail")
+	}
+
+	// Switching algorithms keeps old hashes working, and flags them for a rehash
+	if ok, rehash := bcryptPolicy.Verify(hash, "correct horse"); !ok || !rehash {
+		t.Fatalf("expected an argon2id hash to verify under bcrypt and be rehashed, got ok=%v rehash=%v", ok, rehash)
+	}
+	bcryptHash, _ := bcryptPolicy.Hash("correct horse")
+	if ok, rehash := argonPolicy.Verify(bcryptHash, "correct horse"); !ok || !rehash {
+		t.Fatalf("expected a bcrypt hash to verify under argon2id and be rehashed, got ok=%v rehash=%v", ok, rehash)
+	}
+
+	// So do other parameters
+	argonCfg.Argon2Iterations = 2
+	if ok, rehash := NewPolicy(argonCfg).Verify(hash, "correct horse"); !ok || !rehash {
+		t.Fatalf("expected new argon2id parameters to rehash, got ok=%v rehash=%v", ok, rehash)
+	}
+
+	for _, bad := range []string{"", "$argon2id$v=19$m=64,t=1,p=1$!!$!!", "$argon2id$v=18$m=64,t=1,p=1$c2FsdA$aGFzaA", "plain"} {
+		if ok, _ := argonPolicy.Verify(bad, "correct horse"); ok {
+			t.Errorf("expected malformed hash %q to fail", bad)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	cfg := testConfig()
+	cfg.MinLength = 10
+	cfg.MinCharClasses = 3
+	p := NewPolicy(cfg)
+
+	tests := []struct {
+		password string
+		reasons  int
+	}{
+		{"Tr0ub4dor&3", 0},
+		{"short1A", 1},
+		{"alllowercase", 1},
+		{"short", 2},
+		{"Jane.Doe-2026!", 1}, // Contains the email's local part
+	}
+	for _, tt := range tests {
+		err := p.Check(tt.password, "jane.doe@example.com", "Jane Doe")
+		var weak *WeakError
+		if tt.reasons == 0 {
+			if err != nil {
+				t.Errorf("%q: unexpected error %v", tt.password, err)
+			}
+			continue
+		}
+		if !errors.As(err, &weak) || len(weak.Reasons) != tt.reasons {
+			t.Errorf("%q: expected %d reasons, got %v", tt.password, tt.reasons, err)
+		}
+		if !IsRefused(err) {
+			t.Errorf("%q: expected the error to count as refused", tt.password)
+		}
+	}
+}
+
+func TestLockoutDurationAndExpiry(t *testing.T) {
+	cfg := testConfig()
+	cfg.LockoutDuration = time.Minute
+	cfg.MaxLockoutDuration = 5 * time.Minute
+	cfg.MaxAge = 90 * 24 * time.Hour
+	p := NewPolicy(cfg)
+
+	for n, want := range map[int]time.Duration{1: time.Minute, 2: 2 * time.Minute, 3: 4 * time.Minute, 4: 5 * time.Minute, 20: 5 * time.Minute} {
+		if got := p.LockoutDuration(n); got != want {
+			t.Errorf("lockout %d: got %v, want %v", n, got, want)
+		}
+	}
+
+	now := time.Now()
+	if p.Expired(now.Add(-89*24*time.Hour), now) || !p.Expired(now.Add(-90*24*time.Hour), now) {
+		t.Fatal("expected passwords to expire at max_age")
+	}
+	if NewPolicy(testConfig()).Expired(now.AddDate(-5, 0, 0), now) {
+		t.Fatal("expected passwords not to expire without max_age")
+	}
+}
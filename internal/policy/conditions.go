@@ -0,0 +1,214 @@
+package policy
+
+import (
+	"cmp"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+// Condition operators, following IAM semantics: a condition matches when the
+// key's value matches any of its values, or for the negated operators, none
+// of them. A key the request doesn't have matches only negated operators.
+const (
+	CondStringEquals             = "StringEquals"
+	CondStringNotEquals          = "StringNotEquals"
+	CondStringEqualsIgnoreCase   = "StringEqualsIgnoreCase"
+	CondStringLike               = "StringLike"    // * matches any run of characters, ? one character
+	CondStringNotLike            = "StringNotLike" // Negated StringLike
+	CondNumericEquals            = "NumericEquals"
+	CondNumericNotEquals         = "NumericNotEquals"
+	CondNumericLessThan          = "NumericLessThan"
+	CondNumericLessThanEquals    = "NumericLessThanEquals"
+	CondNumericGreaterThan       = "NumericGreaterThan"
+	CondNumericGreaterThanEquals = "NumericGreaterThanEquals"
+	CondDateEquals               = "DateEquals"
+	CondDateLessThan             = "DateLessThan"
+	CondDateLessThanEquals       = "DateLessThanEquals"
+	CondDateGreaterThan          = "DateGreaterThan"
+	CondDateGreaterThanEquals    = "DateGreaterThanEquals"
+	CondIPAddress                = "IpAddress"    // Values are addresses or CIDR ranges
+	CondNotIPAddress             = "NotIpAddress" // Negated IpAddress
+	CondBool                     = "Bool"
+)
+
+// Condition keys
+const (
+	KeyTenantTier       = "tenant:Tier"
+	KeyTenantStatus     = "tenant:Status"
+	KeyRequestModel     = "request:Model"
+	KeyRequestTokens    = "request:TokenCount" // Estimated prompt tokens
+	KeyRequestMaxTokens = "request:MaxTokens"  // Absent when the request doesn't set max_tokens
+	KeyRequestToolCount = "request:ToolCount"
+	KeyRequestReasoning = "request:HasReasoning"
+	KeyRequestStreaming = "request:Streaming"
+	KeyTimeCurrent      = "time:CurrentTime" // RFC 3339, UTC
+	KeyTimeHour         = "time:Hour"        // 0-23, UTC
+	KeyTimeDayOfWeek    = "time:DayOfWeek"   // Monday, Tuesday, ...
+	KeySourceIP         = "source:IP"        // Absent when the client address is unknown
+)
+
+// negatedConditions match when none of their values match
+var negatedConditions = map[string]bool{
+	CondStringNotEquals:  true,
+	CondStringNotLike:    true,
+	CondNumericNotEquals: true,
+	CondNotIPAddress:     true,
+}
+
+// conditionValue returns the value of a condition key for a request at now,
+// and whether the request has the key
+func conditionValue(key string, tenant *domain.Tenant, req *domain.ChatRequest, now time.Time) (string, bool) {
+	now = now.UTC()
+	switch key {
+	case KeyTenantTier:
+		if tenant != nil {
+			return string(tenant.Tier), true
+		}
+	case KeyTenantStatus:
+		if tenant != nil {
+			return string(tenant.Status), true
+		}
+	case KeyRequestModel:
+		return req.Model, true
+	case KeyRequestTokens:
+		return strconv.Itoa(estimateRequestTokens(req)), true
+	case KeyRequestMaxTokens:
+		if req.MaxTokens != nil {
+			return strconv.Itoa(int(*req.MaxTokens)), true
+		}
+	case KeyRequestToolCount:
+		return strconv.Itoa(len(req.Tools)), true
+	case KeyRequestReasoning:
+		return strconv.FormatBool(req.ReasoningConfig != nil && req.ReasoningConfig.Enabled), true
+	case KeyRequestStreaming:
+		return strconv.FormatBool(req.Streaming), true
+	case KeyTimeCurrent:
+		return now.Format(time.RFC3339), true
+	case KeyTimeHour:
+		return strconv.Itoa(now.Hour()), true
+	case KeyTimeDayOfWeek:
+		return now.Weekday().String(), true
+	case KeySourceIP:
+		if req.ClientIP != "" {
+			return req.ClientIP, true
+		}
+	}
+	return "", false
+}
+
+// estimateRequestTokens roughly estimates a request's prompt tokens at 4
+// characters per token
+func estimateRequestTokens(req *domain.ChatRequest) int {
+	chars := len(req.SystemPrompt) + len(req.Prompt)
+	for _, msg := range req.Messages {
+		for _, block := range msg.Content {
+			if block.Type == "text" {
+				chars += len(block.Text)
+			}
+		}
+	}
+	return chars / 4
+}
+
+// evaluateCondition reports whether a condition holds for a key's value. A
+// value that can't be parsed for the operator matches nothing. Unknown
+// operators don't constrain the statement.
+func evaluateCondition(condition domain.PolicyCondition, value string, present bool) bool {
+	negated := negatedConditions[condition.Operator]
+	if !present {
+		return negated
+	}
+
+	var match func(want string) bool
+	switch condition.Operator {
+	case CondStringEquals, CondStringNotEquals:
+		match = func(want string) bool { return value == want }
+	case CondStringEqualsIgnoreCase:
+		match = func(want string) bool { return strings.EqualFold(value, want) }
+	case CondStringLike, CondStringNotLike:
+		match = func(want string) bool { return matchesPattern(value, want) }
+	case CondNumericEquals, CondNumericNotEquals, CondNumericLessThan, CondNumericLessThanEquals,
+		CondNumericGreaterThan, CondNumericGreaterThanEquals:
+		got, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return negated
+		}
+		match = func(want string) bool {
+			w, err := strconv.ParseFloat(want, 64)
+			return err == nil && compares(condition.Operator, cmp.Compare(got, w))
+		}
+	case CondDateEquals, CondDateLessThan, CondDateLessThanEquals, CondDateGreaterThan, CondDateGreaterThanEquals:
+		got, ok := parseConditionDate(value)
+		if !ok {
+			return false
+		}
+		match = func(want string) bool {
+			w, ok := parseConditionDate(want)
+			return ok && compares(condition.Operator, got.Compare(w))
+		}
+	case CondIPAddress, CondNotIPAddress:
+		addr, err := netip.ParseAddr(value)
+		if err != nil {
+			return negated
+		}
+		addr = addr.Unmap()
+		match = func(want string) bool {
+			if prefix, err := netip.ParsePrefix(want); err == nil {
+				return prefix.Contains(addr)
+			}
+			w, err := netip.ParseAddr(want)
+			return err == nil && w.Unmap() == addr
+		}
+	case CondBool:
+		got, err := strconv.ParseBool(value)
+		if err != nil {
+			return false
+		}
+		match = func(want string) bool {
+			w, err := strconv.ParseBool(want)
+			return err == nil && w == got
+		}
+	default:
+		return true
+	}
+
+	for _, want := range condition.Values {
+		if match(want) {
+			return !negated
+		}
+	}
+	return negated
+}
+
+// compares reports whether a comparison result (-1, 0 or 1) satisfies a
+// numeric or date operator
+func compares(operator string, cmp int) bool {
+	switch operator {
+	case CondNumericEquals, CondNumericNotEquals, CondDateEquals:
+		return cmp == 0
+	case CondNumericLessThan, CondDateLessThan:
+		return cmp < 0
+	case CondNumericLessThanEquals, CondDateLessThanEquals:
+		return cmp <= 0
+	case CondNumericGreaterThan, CondDateGreaterThan:
+		return cmp > 0
+	case CondNumericGreaterThanEquals, CondDateGreaterThanEquals:
+		return cmp >= 0
+	}
+	return false
+}
+
+// parseConditionDate parses an RFC 3339 time or a plain date (midnight UTC)
+func parseConditionDate(s string) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, true
+	}
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
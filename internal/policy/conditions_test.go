@@ -0,0 +1,69 @@
+package policy
+
+import (
+	"testing"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+func TestEvaluateCondition(t *testing.T) {
+	now := time.Date(2026, 3, 31, 22, 30, 0, 0, time.UTC)
+	maxTokens := int32(4096)
+	tenant := &domain.Tenant{Tier: domain.TenantTierProfessional}
+	req := &domain.ChatRequest{
+		Model:     "openai/gpt-4o-mini",
+		Messages:  []domain.Message{{Role: "user", Content: []domain.ContentBlock{{Type: "text", Text: "0123456789abcdefghij0123456789abcdefghij"}}}},
+		Tools:     []domain.Tool{{Type: "function"}, {Type: "function"}},
+		MaxTokens: &maxTokens,
+		ClientIP:  "10.1.2.3",
+	}
+
+	tests := []struct {
+		operator, key string
+		values        []string
+		want          bool
+	}{
+		{CondNumericGreaterThan, KeyRequestTokens, []string{"9"}, true},
+		{CondNumericGreaterThan, KeyRequestTokens, []string{"10"}, false},
+		{CondNumericLessThanEquals, KeyRequestToolCount, []string{"2"}, true},
+		{CondNumericLessThanEquals, KeyRequestToolCount, []string{"1"}, false},
+		{CondNumericGreaterThanEquals, KeyRequestMaxTokens, []string{"8192", "4096"}, true},
+		{CondNumericNotEquals, KeyRequestToolCount, []string{"2"}, false},
+		{CondNumericLessThan, KeyRequestToolCount, []string{"many"}, false},
+		{CondNumericLessThan, KeyTimeHour, []string{"9"}, false},
+		{CondNumericGreaterThanEquals, KeyTimeHour, []string{"22"}, true},
+		{CondDateGreaterThan, KeyTimeCurrent, []string{"2026-03-31"}, true},
+		{CondDateGreaterThan, KeyTimeCurrent, []string{"2026-03-31T23:00:00Z"}, false},
+		{CondDateLessThan, KeyTimeCurrent, []string{"2026-04-01T00:30:00+02:00"}, false},
+		{CondIPAddress, KeySourceIP, []string{"192.168.0.0/16", "10.0.0.0/8"}, true},
+		{CondIPAddress, KeySourceIP, []string{"10.1.2.3"}, true},
+		{CondNotIPAddress, KeySourceIP, []string{"10.0.0.0/8"}, false},
+		{CondNotIPAddress, KeySourceIP, []string{"172.16.0.0/12"}, true},
+		{CondBool, KeyRequestReasoning, []string{"false"}, true},
+		{CondBool, KeyRequestStreaming, []string{"true"}, false},
+		{CondStringLike, KeyRequestModel, []string{"anthropic/*", "openai/gpt-4o*"}, true},
+		{CondStringLike, KeyRequestModel, []string{"openai/gpt-?"}, false},
+		{CondStringNotLike, KeyRequestModel, []string{"*mini"}, false},
+		{CondStringEquals, KeyTenantTier, []string{"free", "professional"}, true},
+		{CondStringEqualsIgnoreCase, KeyTimeDayOfWeek, []string{"TUESDAY"}, true},
+		{CondStringNotEquals, KeyTenantTier, []string{"free"}, true},
+		// Missing keys match only negated operators
+		{CondStringEquals, "request:Unknown", []string{""}, false},
+		{CondStringNotEquals, "request:Unknown", []string{"x"}, true},
+		{"NoSuchOperator", KeyRequestModel, []string{"x"}, true},
+	}
+	for _, tt := range tests {
+		value, present := conditionValue(tt.key, tenant, req, now)
+		cond := domain.PolicyCondition{Operator: tt.operator, Key: tt.key, Values: tt.values}
+		if got := evaluateCondition(cond, value, present); got != tt.want {
+			t.Errorf("%s %s %v on %q: got %t, want %t", tt.operator, tt.key, tt.values, value, got, tt.want)
+		}
+	}
+
+	// Without a client address, source:IP is missing
+	value, present := conditionValue(KeySourceIP, tenant, &domain.ChatRequest{}, now)
+	if evaluateCondition(domain.PolicyCondition{Operator: CondIPAddress, Key: KeySourceIP, Values: []string{"0.0.0.0/0"}}, value, present) {
+		t.Error("expected IpAddress not to match a request without a client address")
+	}
+}
@@ -0,0 +1,288 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"modelgate/internal/domain"
+)
+
+// Dry-run check outcomes
+const (
+	DryRunPass   = "pass"   // The check ran and the request satisfies it
+	DryRunBlock  = "block"  // The request would be rejected
+	DryRunModify = "modify" // The request would go through after being changed
+	DryRunSkip   = "skip"   // Nothing to check for this request
+)
+
+// DryRunStore loads the roles and tool permissions a dry run evaluates
+// (implemented by postgres.TenantStore)
+type DryRunStore interface {
+	GetRole(ctx context.Context, id string) (*domain.Role, error)
+	GetGroupRoles(ctx context.Context, groupID string) ([]*domain.Role, error)
+	GetRoleToolByIdentity(ctx context.Context, roleID, name, schemaHash string) (*domain.RoleTool, error)
+	GetRoleToolByName(ctx context.Context, roleID, name string) (*domain.RoleTool, error)
+}
+
+// DryRunCheck is one step of a dry-run policy evaluation
+type DryRunCheck struct {
+	Role    string `json:"role,omitempty"` // Name of the role whose policy was checked
+	Policy  string `json:"policy"`         // model_restrictions, prompt_policies, tool_policies, rate_limits, generation_policy or tool_permissions
+	Outcome string `json:"outcome"`
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// DryRunResult is the full trace of evaluating a hypothetical request
+type DryRunResult struct {
+	Allowed      bool              `json:"allowed"`
+	Checks       []DryRunCheck     `json:"checks"`
+	Tools        []ToolCheckResult `json:"tools,omitempty"`         // Permission of each tool for the role
+	RemovedTools []string          `json:"removed_tools,omitempty"` // Tools that would be stripped from the request
+}
+
+// Add records a check, marking the result blocked when it would block
+func (r *DryRunResult) Add(check DryRunCheck) {
+	if check.Outcome == DryRunBlock {
+		r.Allowed = false
+	}
+	r.Checks = append(r.Checks, check)
+}
+
+// DryRun evaluates a request against the policies of its role or group the
+// way the gateway would, without forwarding it, consuming rate limits,
+// registering tools or recording anything. Unlike live enforcement, which
+// stops at the first violation, every check runs so the trace shows all that
+// would block.
+func (s *EnforcementService) DryRun(ctx context.Context, store DryRunStore, req *domain.ChatRequest) (*DryRunResult, error) {
+	result := &DryRunResult{Allowed: true}
+
+	var roles []*domain.Role
+	var keyRole *domain.Role // The key's own role, whose tool permissions apply
+	if req.RoleID != "" {
+		role, err := store.GetRole(ctx, req.RoleID)
+		if err != nil {
+			return nil, fmt.Errorf("load role: %w", err)
+		}
+		if role != nil && role.Policy != nil {
+			roles = append(roles, role)
+			keyRole = role
+		}
+	}
+	if req.GroupID != "" {
+		groupRoles, err := store.GetGroupRoles(ctx, req.GroupID)
+		if err != nil {
+			return nil, fmt.Errorf("load group roles: %w", err)
+		}
+		for _, role := range groupRoles {
+			if role.Policy != nil {
+				roles = append(roles, role)
+			}
+		}
+	}
+	if len(roles) == 0 {
+		result.Add(DryRunCheck{
+			Policy:  "role_policy",
+			Outcome: DryRunBlock,
+			Code:    "no_policy_configured",
+			Message: "No policy configured for this API key",
+		})
+		return result, nil
+	}
+
+	for _, role := range roles {
+		enfCtx := &EnforcementContext{
+			APIKeyID: req.APIKeyID,
+			ModelID:  req.Model,
+			Messages: cloneMessages(req.Messages),
+			Tools:    req.Tools,
+			RoleID:   role.ID,
+			Policy:   role.Policy,
+		}
+		for _, check := range s.dryRunChecks(ctx, enfCtx, req.Messages) {
+			check.Role = role.Name
+			result.Add(check)
+		}
+		check := dryRunGeneration(req, &role.Policy.GenerationPolicy)
+		check.Role = role.Name
+		result.Add(check)
+	}
+
+	// Tool calling being disabled is already reported by the tool policy check
+	if len(req.Tools) > 0 && keyRole != nil && keyRole.Policy.ToolPolicies.AllowToolCalling {
+		if err := dryRunToolPermissions(ctx, store, keyRole, req.Tools, result); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// dryRunChecks runs each of a role policy's enforcement checks. original is
+// the request's messages before enforcement, to tell whether PII handling
+// would rewrite them.
+func (s *EnforcementService) dryRunChecks(ctx context.Context, enfCtx *EnforcementContext, original []domain.Message) []DryRunCheck {
+	checks := []DryRunCheck{
+		checkOutcome("model_restrictions", s.validateModelRestrictions(enfCtx)),
+		checkOutcome("prompt_policies", s.validatePromptPolicies(ctx, enfCtx)),
+		checkOutcome("tool_policies", s.validateToolPolicies(enfCtx)),
+		s.dryRunRateLimits(enfCtx),
+	}
+	if checks[1].Outcome == DryRunPass && !slices.EqualFunc(enfCtx.Messages, original, messagesEqual) {
+		checks[1].Outcome = DryRunModify
+		checks[1].Code = "pii_rewritten"
+		checks[1].Message = "Personal information in the prompt would be redacted or rewritten"
+	} else if checks[1].Outcome == DryRunPass && len(enfCtx.Flags) > 0 {
+		checks[1].Code = enfCtx.Flags[0].Code
+		checks[1].Message = enfCtx.Flags[0].Message + " (flagged, not blocked)"
+	}
+	return checks
+}
+
+// dryRunRateLimits checks the role's rate limits against the API key's
+// current windows without consuming from them
+func (s *EnforcementService) dryRunRateLimits(enfCtx *EnforcementContext) DryRunCheck {
+	ratePolicy := enfCtx.Policy.RateLimitPolicy
+	if ratePolicy.RequestsPerMinute == 0 && ratePolicy.TokensPerMinute == 0 {
+		return DryRunCheck{Policy: "rate_limits", Outcome: DryRunSkip}
+	}
+
+	identifier := fmt.Sprintf("%s:%s", enfCtx.TenantID, enfCtx.APIKeyID)
+	if ratePolicy.RequestsPerMinute > 0 {
+		credits := BurstCredits(ratePolicy.RequestsPerMinute, ratePolicy.BurstMultiplier, ratePolicy.BurstLimit)
+		if result := s.rateLimiter.PeekRequest(identifier, ratePolicy.RequestsPerMinute, credits); !result.Allowed {
+			return checkOutcome("rate_limits", &PolicyViolation{
+				Code:    "rate_limit_exceeded",
+				Message: rateLimitMessage(fmt.Sprintf("Rate limit exceeded: %d requests per minute", ratePolicy.RequestsPerMinute), result.Window),
+			})
+		}
+	}
+	if ratePolicy.TokensPerMinute > 0 {
+		credits := BurstCredits(int(ratePolicy.TokensPerMinute), ratePolicy.BurstMultiplier, int(ratePolicy.BurstTokens))
+		if result := s.rateLimiter.PeekTokens(identifier, s.estimateTokens(enfCtx.Messages), int(ratePolicy.TokensPerMinute), credits); !result.Allowed {
+			return checkOutcome("rate_limits", &PolicyViolation{
+				Code:    "token_rate_limit_exceeded",
+				Message: rateLimitMessage(fmt.Sprintf("Token rate limit exceeded: %d tokens per minute", ratePolicy.TokensPerMinute), result.Window),
+			})
+		}
+	}
+	return DryRunCheck{Policy: "rate_limits", Outcome: DryRunPass}
+}
+
+// dryRunGeneration reports the client parameters the role's generation
+// policy would clamp
+func dryRunGeneration(req *domain.ChatRequest, gp *domain.GenerationPolicy) DryRunCheck {
+	if !gp.Enabled {
+		return DryRunCheck{Policy: "generation_policy", Outcome: DryRunSkip}
+	}
+	params := &domain.ChatRequest{Temperature: req.Temperature, TopP: req.TopP, MaxTokens: req.MaxTokens}
+	clamped := ApplyGenerationPolicy(params, gp)
+	if len(clamped) == 0 {
+		return DryRunCheck{Policy: "generation_policy", Outcome: DryRunPass}
+	}
+	changes := make([]string, len(clamped))
+	for i, c := range clamped {
+		changes[i] = fmt.Sprintf("%s %s to %s", c.Name, formatParam(c.From), formatParam(c.Value))
+	}
+	return DryRunCheck{
+		Policy:  "generation_policy",
+		Outcome: DryRunModify,
+		Code:    "params_clamped",
+		Message: "Generation parameters would be clamped: " + strings.Join(changes, ", "),
+	}
+}
+
+// dryRunToolPermissions looks up the role's decision for each tool. Tools the
+// role hasn't seen yet are treated as newly discovered, pending review.
+func dryRunToolPermissions(ctx context.Context, store DryRunStore, role *domain.Role, tools []domain.Tool, result *DryRunResult) error {
+	var known []*domain.RoleTool
+	for _, tool := range tools {
+		if tool.Type != "function" {
+			continue
+		}
+		var existing *domain.RoleTool
+		var err error
+		if tool.Function.Name == "tool_search" {
+			if existing, err = store.GetRoleToolByName(ctx, role.ID, "tool_search"); err != nil || existing == nil {
+				continue // Not registered from requests, so never checked
+			}
+		} else {
+			parameters := tool.Function.Parameters
+			if parameters == nil {
+				parameters = map[string]any{}
+			}
+			existing, err = store.GetRoleToolByIdentity(ctx, role.ID, tool.Function.Name, ComputeSchemaHash(parameters))
+			if err != nil {
+				return fmt.Errorf("look up tool %s: %w", tool.Function.Name, err)
+			}
+		}
+		if existing == nil {
+			existing = &domain.RoleTool{RoleID: role.ID, Name: tool.Function.Name, Status: domain.ToolStatusPending}
+		}
+		known = append(known, existing)
+	}
+	if len(known) == 0 {
+		return nil
+	}
+
+	toolPolicies := domain.DefaultEnhancedToolPolicies()
+	if role.Policy.ToolPolicies.RequireToolApproval {
+		toolPolicies.DefaultAction = "BLOCK"
+	}
+	perms, err := NewToolDiscoveryService().CheckToolPermissions(ctx, role.ID, known, toolPolicies, nil)
+	if err != nil {
+		return err
+	}
+	result.Tools = perms.Tools
+
+	check := DryRunCheck{Role: role.Name, Policy: "tool_permissions", Outcome: DryRunPass}
+	for _, t := range perms.RemovedTools() {
+		result.RemovedTools = append(result.RemovedTools, t.ToolName)
+	}
+	if len(result.RemovedTools) > 0 {
+		check.Outcome = DryRunModify
+		check.Code = "tools_removed"
+		check.Message = fmt.Sprintf("%d tool(s) would be removed from the request", len(result.RemovedTools))
+	}
+	if blocked := perms.BlockedTools(); len(blocked) > 0 {
+		names := make([]string, len(blocked))
+		for i, t := range blocked {
+			names[i] = t.ToolName
+		}
+		check.Outcome = DryRunBlock
+		check.Code = "tool_not_approved"
+		check.Message = fmt.Sprintf("Tools not approved for this role: %v", names)
+	}
+	result.Add(check)
+	return nil
+}
+
+// checkOutcome turns the result of an enforcement check into a dry-run check
+func checkOutcome(policy string, err error) DryRunCheck {
+	if err == nil {
+		return DryRunCheck{Policy: policy, Outcome: DryRunPass}
+	}
+	check := DryRunCheck{Policy: policy, Outcome: DryRunBlock, Message: err.Error()}
+	if v, ok := err.(*PolicyViolation); ok {
+		check.Code, check.Message = v.Code, v.Message
+	}
+	return check
+}
+
+// cloneMessages copies messages deeply enough that PII redaction of the copy
+// leaves the originals untouched
+func cloneMessages(messages []domain.Message) []domain.Message {
+	cloned := make([]domain.Message, len(messages))
+	for i, msg := range messages {
+		cloned[i] = msg
+		cloned[i].Content = slices.Clone(msg.Content)
+	}
+	return cloned
+}
+
+func messagesEqual(a, b domain.Message) bool {
+	return slices.EqualFunc(a.Content, b.Content, func(x, y domain.ContentBlock) bool {
+		return x.Type == y.Type && x.Text == y.Text
+	})
+}
@@ -0,0 +1,95 @@
+package policy
+
+import (
+	"context"
+	"testing"
+
+	"modelgate/internal/domain"
+)
+
+type dryRunStore struct {
+	role  *domain.Role
+	tools map[string]*domain.RoleTool
+}
+
+func (s *dryRunStore) GetRole(ctx context.Context, id string) (*domain.Role, error) {
+	return s.role, nil
+}
+
+func (s *dryRunStore) GetGroupRoles(ctx context.Context, groupID string) ([]*domain.Role, error) {
+	return nil, nil
+}
+
+func (s *dryRunStore) GetRoleToolByIdentity(ctx context.Context, roleID, name, schemaHash string) (*domain.RoleTool, error) {
+	return s.tools[name], nil
+}
+
+func (s *dryRunStore) GetRoleToolByName(ctx context.Context, roleID, name string) (*domain.RoleTool, error) {
+	return s.tools[name], nil
+}
+
+func TestDryRunReportsEveryViolation(t *testing.T) {
+	rolePolicy := domain.DefaultRolePolicy("role-1", "support")
+	rolePolicy.ModelRestriction.AllowedModels = []string{"gpt-4o"}
+	rolePolicy.RateLimitPolicy.RequestsPerMinute = 1
+	rolePolicy.ToolPolicies.AllowToolCalling = true
+	store := &dryRunStore{
+		role: &domain.Role{ID: "role-1", Name: "support", Policy: rolePolicy},
+		tools: map[string]*domain.RoleTool{
+			"search": {Name: "search", Status: domain.ToolStatusAllowed},
+			"shell":  {Name: "shell", Status: domain.ToolStatusRemoved},
+		},
+	}
+	s := NewEnforcementService()
+	req := &domain.ChatRequest{
+		Model:    "claude-opus",
+		APIKeyID: "key-1",
+		RoleID:   "role-1",
+		Messages: []domain.Message{{Role: "user", Content: []domain.ContentBlock{{Type: "text", Text: "hello"}}}},
+		Tools: []domain.Tool{
+			{Type: "function", Function: domain.FunctionDefinition{Name: "search"}},
+			{Type: "function", Function: domain.FunctionDefinition{Name: "shell"}},
+		},
+	}
+
+	result, err := s.DryRun(context.Background(), store, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outcomes := map[string]string{}
+	for _, c := range result.Checks {
+		outcomes[c.Policy] = c.Outcome
+	}
+	want := map[string]string{
+		"model_restrictions": DryRunBlock,
+		"prompt_policies":    DryRunPass,
+		"rate_limits":        DryRunPass,
+		"tool_permissions":   DryRunModify,
+	}
+	for policy, outcome := range want {
+		if outcomes[policy] != outcome {
+			t.Errorf("%s: got %q, want %q", policy, outcomes[policy], outcome)
+		}
+	}
+	if result.Allowed {
+		t.Error("request with a disallowed model should not be allowed")
+	}
+	if len(result.RemovedTools) != 1 || result.RemovedTools[0] != "shell" {
+		t.Errorf("removed tools: got %v, want [shell]", result.RemovedTools)
+	}
+
+	// Dry runs never consume the rate limit, but live traffic does
+	if _, err := s.DryRun(context.Background(), store, req); err != nil {
+		t.Fatal(err)
+	}
+	s.rateLimiter.ConsumeRequest(":key-1", 1, 0)
+	result, err = s.DryRun(context.Background(), store, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range result.Checks {
+		if c.Policy == "rate_limits" && c.Outcome != DryRunBlock {
+			t.Errorf("rate_limits after the limit was used: got %q, want %q", c.Outcome, DryRunBlock)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+
+	"modelgate/internal/domain"
+)
+
+// =============================================================================
+// Embedding Restrictions
+// =============================================================================
+
+// CheckEmbeddingRequest checks an embedding request against a role's model
+// restrictions: the model must be one of the role's embedding models, and the
+// batch and any requested dimensions must be within the role's caps
+func CheckEmbeddingRequest(req *domain.EmbeddingRequest, restrictions *domain.ModelRestrictions) error {
+	if len(restrictions.AllowedEmbeddingModels) > 0 && !embeddingModelAllowed(req.Model, restrictions.AllowedEmbeddingModels) {
+		return &PolicyViolation{
+			Code:    "model_not_allowed",
+			Message: fmt.Sprintf("Embedding model '%s' is not in the allowed list", req.Model),
+			Type:    "model",
+		}
+	}
+	if limit := restrictions.MaxEmbeddingInputs; limit > 0 && len(req.Input) > limit {
+		return &PolicyViolation{
+			Code:    "too_many_inputs",
+			Message: fmt.Sprintf("The request has %d inputs; at most %d are allowed per request", len(req.Input), limit),
+			Type:    "embedding",
+		}
+	}
+	if limit := restrictions.MaxEmbeddingDimensions; limit > 0 && req.Dimensions != nil && *req.Dimensions > limit {
+		return &PolicyViolation{
+			Code:    "dimensions_not_allowed",
+			Message: fmt.Sprintf("%d dimensions were requested; at most %d are allowed", *req.Dimensions, limit),
+			Type:    "embedding",
+		}
+	}
+	return nil
+}
+
+// embeddingModelAllowed matches a model against an allowed list whose entries
+// may or may not name the provider
+func embeddingModelAllowed(model string, allowed []string) bool {
+	_, bare, prefixed := strings.Cut(model, "/")
+	for _, m := range allowed {
+		if m == model || (prefixed && m == bare) {
+			return true
+		}
+	}
+	return false
+}
+
+// EmbeddingDimensionsCap returns the tightest dimension cap of the roles'
+// restrictions, or 0 if none caps them
+func EmbeddingDimensionsCap(policies []*domain.RolePolicy) int32 {
+	var limit int32
+	for _, p := range policies {
+		if c := p.ModelRestriction.MaxEmbeddingDimensions; c > 0 && (limit == 0 || c < limit) {
+			limit = c
+		}
+	}
+	return limit
+}
@@ -0,0 +1,61 @@
+package policy
+
+import (
+	"testing"
+
+	"modelgate/internal/domain"
+)
+
+func TestCheckEmbeddingRequest(t *testing.T) {
+	restrictions := &domain.ModelRestrictions{
+		AllowedEmbeddingModels: []string{"text-embedding-3-small", "gemini/text-embedding-004"},
+		MaxEmbeddingDimensions: 512,
+		MaxEmbeddingInputs:     2,
+	}
+	dims := func(n int32) *int32 { return &n }
+
+	tests := []struct {
+		name string
+		req  domain.EmbeddingRequest
+		code string
+	}{
+		{"bare model", domain.EmbeddingRequest{Model: "text-embedding-3-small", Input: []string{"a"}}, ""},
+		{"provider prefix", domain.EmbeddingRequest{Model: "openai/text-embedding-3-small", Input: []string{"a"}}, ""},
+		{"listed with provider", domain.EmbeddingRequest{Model: "gemini/text-embedding-004", Input: []string{"a"}, Dimensions: dims(512)}, ""},
+		{"provider must match", domain.EmbeddingRequest{Model: "text-embedding-004", Input: []string{"a"}}, "model_not_allowed"},
+		{"other model", domain.EmbeddingRequest{Model: "openai/text-embedding-3-large", Input: []string{"a"}}, "model_not_allowed"},
+		{"batch", domain.EmbeddingRequest{Model: "text-embedding-3-small", Input: []string{"a", "b", "c"}}, "too_many_inputs"},
+		{"dimensions", domain.EmbeddingRequest{Model: "text-embedding-3-small", Input: []string{"a"}, Dimensions: dims(1024)}, "dimensions_not_allowed"},
+	}
+	for _, tt := range tests {
+		err := CheckEmbeddingRequest(&tt.req, restrictions)
+		var code string
+		if v, ok := err.(*PolicyViolation); ok {
+			code = v.Code
+		} else if err != nil {
+			t.Fatalf("%s: unexpected error %v", tt.name, err)
+		}
+		if code != tt.code {
+			t.Errorf("%s: got %q, want %q", tt.name, code, tt.code)
+		}
+	}
+
+	// Roles without embedding restrictions allow any request
+	if err := CheckEmbeddingRequest(&domain.EmbeddingRequest{Model: "x", Input: make([]string, 100), Dimensions: dims(4096)}, &domain.ModelRestrictions{}); err != nil {
+		t.Fatalf("expected an unrestricted role to allow the request, got %v", err)
+	}
+}
+
+func TestEmbeddingDimensionsCap(t *testing.T) {
+	policies := []*domain.RolePolicy{
+		{ModelRestriction: domain.ModelRestrictions{MaxEmbeddingDimensions: 1024}},
+		{},
+		{ModelRestriction: domain.ModelRestrictions{MaxEmbeddingDimensions: 256}},
+	}
+	if got := EmbeddingDimensionsCap(policies); got != 256 {
+		t.Fatalf("expected the tightest cap, got %d", got)
+	}
+	if got := EmbeddingDimensionsCap(policies[1:2]); got != 0 {
+		t.Fatalf("expected no cap, got %d", got)
+	}
+}
@@ -19,7 +19,8 @@ import (
 
 // EnforcementService enforces policies for all LLM operations
 type EnforcementService struct {
-	rateLimiter *RateLimiter
+	rateLimiter      *RateLimiter
+	injectionMatcher InjectionMatcher
 }
 
 // NewEnforcementService creates a new policy enforcement service
@@ -39,6 +40,12 @@ type EnforcementContext struct {
 	RoleID   string
 	GroupID  string
 	Policy   *domain.RolePolicy
+
+	// Set by enforcement when rate limits apply
+	RateLimit *domain.RateLimitState
+
+	// Detections the policy flags without blocking the request
+	Flags []*PolicyViolation
 }
 
 // PolicyViolation represents a policy violation error
@@ -46,6 +53,12 @@ type PolicyViolation struct {
 	Code    string `json:"code"`
 	Message string `json:"message"`
 	Type    string `json:"type"` // model, prompt, tool, rate_limit
+
+	// For rate limit violations, the windows the request was refused under
+	RateLimit *domain.RateLimitState `json:"-"`
+
+	// For injection similarity detections, the known attack the text resembled
+	Outlier *domain.OutlierAnalysis `json:"-"`
 }
 
 func (e *PolicyViolation) Error() string {
@@ -69,7 +82,7 @@ func (s *EnforcementService) EnforcePolicy(ctx context.Context, enfCtx *Enforcem
 	}
 
 	// 2. Prompt Policy Check
-	if err := s.validatePromptPolicies(enfCtx); err != nil {
+	if err := s.validatePromptPolicies(ctx, enfCtx); err != nil {
 		return err
 	}
 
@@ -118,7 +131,7 @@ func (s *EnforcementService) validateModelRestrictions(enfCtx *EnforcementContex
 // 2. Prompt Policy Validation
 // =============================================================================
 
-func (s *EnforcementService) validatePromptPolicies(enfCtx *EnforcementContext) error {
+func (s *EnforcementService) validatePromptPolicies(ctx context.Context, enfCtx *EnforcementContext) error {
 	promptPolicy := enfCtx.Policy.PromptPolicies
 
 	// Policy feature flags
@@ -229,6 +242,11 @@ func (s *EnforcementService) validatePromptPolicies(enfCtx *EnforcementContext)
 		}
 	}
 
+	// Compare with the corpus of known attacks when ML detection is on
+	if err := s.validateInjectionSimilarity(ctx, enfCtx); err != nil {
+		return err
+	}
+
 	// PII scanning using PIIPolicy
 	// Only scan the latest user message for input PII, not the entire conversation history
 	if piiEnabled {
@@ -398,32 +416,32 @@ func (s *EnforcementService) detectPromptInjection(content string, patternConfig
 	return false
 }
 
+// piiPatterns detects personally identifiable information by category
+var piiPatterns = map[string]*regexp.Regexp{
+	// Email: standard email format
+	"email": regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Z|a-z]{2,}\b`),
+	// Phone: 408-325-6890, 408.325.6890, 408 325 6890, 4083256890, +1-408-325-6890
+	"phone": regexp.MustCompile(`\b(?:\+?1[-.\s]?)?\d{3}[-.\s]?\d{3}[-.\s]?\d{4}\b`),
+	// SSN: 123-45-6789
+	"ssn": regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`),
+	// Credit card: 4111-1111-1111-1111 or 4111111111111111
+	"credit_card": regexp.MustCompile(`\b\d{4}[-\s]?\d{4}[-\s]?\d{4}[-\s]?\d{4}\b`),
+	// IP address: IPv4 format like 192.168.1.1
+	"ip_address": regexp.MustCompile(`\b(?:(?:25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)\.){3}(?:25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)\b`),
+	// Date of birth: MM/DD/YYYY, MM-DD-YYYY, YYYY-MM-DD, DD/MM/YYYY
+	"dob": regexp.MustCompile(`\b(?:\d{1,2}[-/]\d{1,2}[-/]\d{2,4}|\d{4}[-/]\d{1,2}[-/]\d{1,2})\b`),
+	// Address: Street addresses like "123 Main St", "456 Oak Avenue, Apt 7"
+	"address": regexp.MustCompile(`(?i)\b\d{1,5}\s+(?:[A-Za-z]+\s+){1,4}(?:street|st|avenue|ave|road|rd|boulevard|blvd|drive|dr|lane|ln|way|court|ct|circle|cir|place|pl|terrace|ter)\b(?:\s*,?\s*(?:apt|apartment|suite|ste|unit|#)\s*\d+[A-Za-z]?)?`),
+	// Name: Common patterns like "Name: John Smith", "Full Name: Jane Doe"
+	// Also matches "Mr./Mrs./Ms./Dr. Firstname Lastname"
+	"name": regexp.MustCompile(`(?i)(?:(?:name|full\s*name|customer|patient|user)\s*:\s*([A-Z][a-z]+(?:\s+[A-Z][a-z]+)+))|(?:(?:Mr\.?|Mrs\.?|Ms\.?|Dr\.?|Miss)\s+[A-Z][a-z]+(?:\s+[A-Z][a-z]+)+)`),
+}
+
 // detectPII detects personally identifiable information
 func (s *EnforcementService) detectPII(content string, categories []string) string {
-	// Comprehensive PII patterns
-	patterns := map[string]*regexp.Regexp{
-		// Email: standard email format
-		"email": regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Z|a-z]{2,}\b`),
-		// Phone: 408-325-6890, 408.325.6890, 408 325 6890, 4083256890, +1-408-325-6890
-		"phone": regexp.MustCompile(`\b(?:\+?1[-.\s]?)?\d{3}[-.\s]?\d{3}[-.\s]?\d{4}\b`),
-		// SSN: 123-45-6789
-		"ssn": regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`),
-		// Credit card: 4111-1111-1111-1111 or 4111111111111111
-		"credit_card": regexp.MustCompile(`\b\d{4}[-\s]?\d{4}[-\s]?\d{4}[-\s]?\d{4}\b`),
-		// IP address: IPv4 format like 192.168.1.1
-		"ip_address": regexp.MustCompile(`\b(?:(?:25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)\.){3}(?:25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)\b`),
-		// Date of birth: MM/DD/YYYY, MM-DD-YYYY, YYYY-MM-DD, DD/MM/YYYY
-		"dob": regexp.MustCompile(`\b(?:\d{1,2}[-/]\d{1,2}[-/]\d{2,4}|\d{4}[-/]\d{1,2}[-/]\d{1,2})\b`),
-		// Address: Street addresses like "123 Main St", "456 Oak Avenue, Apt 7"
-		"address": regexp.MustCompile(`(?i)\b\d{1,5}\s+(?:[A-Za-z]+\s+){1,4}(?:street|st|avenue|ave|road|rd|boulevard|blvd|drive|dr|lane|ln|way|court|ct|circle|cir|place|pl|terrace|ter)\b(?:\s*,?\s*(?:apt|apartment|suite|ste|unit|#)\s*\d+[A-Za-z]?)?`),
-		// Name: Common patterns like "Name: John Smith", "Full Name: Jane Doe"
-		// Also matches "Mr./Mrs./Ms./Dr. Firstname Lastname"
-		"name": regexp.MustCompile(`(?i)(?:(?:name|full\s*name|customer|patient|user)\s*:\s*([A-Z][a-z]+(?:\s+[A-Z][a-z]+)+))|(?:(?:Mr\.?|Mrs\.?|Ms\.?|Dr\.?|Miss)\s+[A-Z][a-z]+(?:\s+[A-Z][a-z]+)+)`),
-	}
-
 	// If no specific categories, check all
 	if len(categories) == 0 {
-		for category, pattern := range patterns {
+		for category, pattern := range piiPatterns {
 			if pattern.MatchString(content) {
 				return category
 			}
@@ -431,7 +449,7 @@ func (s *EnforcementService) detectPII(content string, categories []string) stri
 	} else {
 		// Check only specified categories
 		for _, category := range categories {
-			if pattern, exists := patterns[category]; exists {
+			if pattern, exists := piiPatterns[category]; exists {
 				if pattern.MatchString(content) {
 					return category
 				}
@@ -850,33 +868,64 @@ func (s *EnforcementService) validateRateLimits(ctx context.Context, enfCtx *Enf
 	}
 
 	identifier := fmt.Sprintf("%s:%s", enfCtx.TenantID, enfCtx.APIKeyID)
+	state := &domain.RateLimitState{}
 
 	// Check requests per minute
 	if ratePolicy.RequestsPerMinute > 0 {
-		if !s.rateLimiter.AllowRequest(identifier, ratePolicy.RequestsPerMinute) {
+		credits := BurstCredits(ratePolicy.RequestsPerMinute, ratePolicy.BurstMultiplier, ratePolicy.BurstLimit)
+		result := s.rateLimiter.ConsumeRequest(identifier, ratePolicy.RequestsPerMinute, credits)
+		state.Requests = &result.Window
+		if !result.Allowed {
+			state.Exceeded = state.Requests
 			return &PolicyViolation{
-				Code:    "rate_limit_exceeded",
-				Message: fmt.Sprintf("Rate limit exceeded: %d requests per minute", ratePolicy.RequestsPerMinute),
-				Type:    "rate_limit",
+				Code:      "rate_limit_exceeded",
+				Message:   rateLimitMessage(fmt.Sprintf("Rate limit exceeded: %d requests per minute", ratePolicy.RequestsPerMinute), result.Window),
+				Type:      "rate_limit",
+				RateLimit: state,
 			}
 		}
+		state.BurstRequests = result.BurstUsed
 	}
 
 	// Check tokens per minute (estimated based on message length)
 	if ratePolicy.TokensPerMinute > 0 {
 		estimatedTokens := s.estimateTokens(enfCtx.Messages)
-		if !s.rateLimiter.AllowTokens(identifier, estimatedTokens, int(ratePolicy.TokensPerMinute)) {
+		credits := BurstCredits(int(ratePolicy.TokensPerMinute), ratePolicy.BurstMultiplier, int(ratePolicy.BurstTokens))
+		result := s.rateLimiter.ConsumeTokens(identifier, estimatedTokens, int(ratePolicy.TokensPerMinute), credits)
+		state.Tokens = &result.Window
+		if !result.Allowed {
+			state.Exceeded = state.Tokens
 			return &PolicyViolation{
-				Code:    "token_rate_limit_exceeded",
-				Message: fmt.Sprintf("Token rate limit exceeded: %d tokens per minute", ratePolicy.TokensPerMinute),
-				Type:    "rate_limit",
+				Code:      "token_rate_limit_exceeded",
+				Message:   rateLimitMessage(fmt.Sprintf("Token rate limit exceeded: %d tokens per minute", ratePolicy.TokensPerMinute), result.Window),
+				Type:      "rate_limit",
+				RateLimit: state,
 			}
 		}
+		state.BurstTokens = result.BurstUsed
 	}
 
+	enfCtx.RateLimit = state
 	return nil
 }
 
+// RateLimitStatus reports an API key's current windows under a role's rate
+// limits without consuming from them. Returns nil if the role has none.
+func (s *EnforcementService) RateLimitStatus(tenantID, apiKeyID string, ratePolicy domain.RateLimitPolicy) *domain.RateLimitState {
+	if ratePolicy.RequestsPerMinute == 0 && ratePolicy.TokensPerMinute == 0 {
+		return nil
+	}
+	return s.rateLimiter.Status(fmt.Sprintf("%s:%s", tenantID, apiKeyID), ratePolicy.RequestsPerMinute, int(ratePolicy.TokensPerMinute))
+}
+
+// rateLimitMessage explains a rate limit rejection, noting when burst credits are being repaid
+func rateLimitMessage(message string, window domain.RateLimitWindow) string {
+	if window.Payback > 0 {
+		return fmt.Sprintf("%s (%d burst credits from the previous minute are being repaid)", message, window.Payback)
+	}
+	return message
+}
+
 // estimateTokens provides a rough estimate of token count
 func (s *EnforcementService) estimateTokens(messages []domain.Message) int {
 	totalChars := 0
@@ -906,6 +955,8 @@ type tokenBucket struct {
 	tokens     int
 	lastRefill time.Time
 	capacity   int
+	borrowed   int // Burst credits borrowed in the current window
+	payback    int // Credits borrowed in the previous window, repaid in this one
 }
 
 // NewRateLimiter creates a new rate limiter
@@ -921,77 +972,156 @@ func NewRateLimiter() *RateLimiter {
 	return rl
 }
 
+// RateLimitResult is the outcome of consuming from a rate limit window
+type RateLimitResult struct {
+	Allowed   bool
+	Window    domain.RateLimitWindow
+	BurstUsed int // Burst credits borrowed for this request
+}
+
 // AllowRequest checks if a request is allowed based on rate limit
 func (rl *RateLimiter) AllowRequest(identifier string, ratePerMinute int) bool {
+	return rl.ConsumeRequest(identifier, ratePerMinute, 0).Allowed
+}
+
+// AllowTokens checks if token consumption is allowed
+func (rl *RateLimiter) AllowTokens(identifier string, tokensNeeded, ratePerMinute int) bool {
+	return rl.ConsumeTokens(identifier, tokensNeeded, ratePerMinute, 0).Allowed
+}
+
+// ConsumeRequest takes one request from the window, borrowing up to burstCredits
+// from the next window when the current one is exhausted
+func (rl *RateLimiter) ConsumeRequest(identifier string, ratePerMinute, burstCredits int) RateLimitResult {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
+	return rl.consume(rl.requestBuckets, identifier, 1, ratePerMinute, burstCredits)
+}
 
-	bucket, exists := rl.requestBuckets[identifier]
-	if !exists {
-		bucket = &tokenBucket{
-			tokens:     ratePerMinute - 1,
-			lastRefill: time.Now(),
-			capacity:   ratePerMinute,
-		}
-		rl.requestBuckets[identifier] = bucket
-		return true
-	}
+// ConsumeTokens takes tokensNeeded tokens from the window, borrowing up to
+// burstCredits from the next window when the current one is exhausted
+func (rl *RateLimiter) ConsumeTokens(identifier string, tokensNeeded, ratePerMinute, burstCredits int) RateLimitResult {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	return rl.consume(rl.tokenBuckets, identifier, tokensNeeded, ratePerMinute, burstCredits)
+}
 
-	// Update capacity if rate limit changed
-	if bucket.capacity != ratePerMinute {
-		bucket.capacity = ratePerMinute
-		if bucket.tokens > ratePerMinute {
-			bucket.tokens = ratePerMinute
-		}
-	}
+// PeekRequest reports whether ConsumeRequest would allow a request, without
+// taking anything from the window
+func (rl *RateLimiter) PeekRequest(identifier string, ratePerMinute, burstCredits int) RateLimitResult {
+	rl.mu.RLock()
+	defer rl.mu.RUnlock()
+	return rl.peek(rl.requestBuckets, identifier, 1, ratePerMinute, burstCredits)
+}
 
-	// Refill tokens based on time elapsed
-	now := time.Now()
-	if now.Sub(bucket.lastRefill) >= time.Minute {
-		bucket.tokens = bucket.capacity
-		bucket.lastRefill = now
-	}
+// PeekTokens reports whether ConsumeTokens would allow tokensNeeded tokens,
+// without taking anything from the window
+func (rl *RateLimiter) PeekTokens(identifier string, tokensNeeded, ratePerMinute, burstCredits int) RateLimitResult {
+	rl.mu.RLock()
+	defer rl.mu.RUnlock()
+	return rl.peek(rl.tokenBuckets, identifier, tokensNeeded, ratePerMinute, burstCredits)
+}
 
-	// Check if tokens available
-	if bucket.tokens > 0 {
-		bucket.tokens--
-		return true
+// Status reports the identifier's request and token windows without taking
+// anything from them. A zero rate leaves that window out.
+func (rl *RateLimiter) Status(identifier string, requestsPerMinute, tokensPerMinute int) *domain.RateLimitState {
+	rl.mu.RLock()
+	defer rl.mu.RUnlock()
+	state := &domain.RateLimitState{}
+	if requestsPerMinute > 0 {
+		result := rl.peek(rl.requestBuckets, identifier, 0, requestsPerMinute, 0)
+		state.Requests = &result.Window
 	}
+	if tokensPerMinute > 0 {
+		result := rl.peek(rl.tokenBuckets, identifier, 0, tokensPerMinute, 0)
+		state.Tokens = &result.Window
+	}
+	return state
+}
 
-	return false
+// peek runs consume against a copy of the identifier's bucket. Callers must
+// hold rl.mu for reading.
+func (rl *RateLimiter) peek(buckets map[string]*tokenBucket, identifier string, needed, capacity, burstCredits int) RateLimitResult {
+	probe := map[string]*tokenBucket{}
+	if bucket, ok := buckets[identifier]; ok {
+		copied := *bucket
+		probe[identifier] = &copied
+	}
+	return rl.consume(probe, identifier, needed, capacity, burstCredits)
 }
 
-// AllowTokens checks if token consumption is allowed
-func (rl *RateLimiter) AllowTokens(identifier string, tokensNeeded, ratePerMinute int) bool {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
+// consume implements fixed one-minute windows with burst credits. Credits
+// borrowed in a window are repaid by starting the next window with that much
+// less capacity; no new credits can be borrowed while repaying. A window that
+// passes without any traffic repays the debt. Callers must hold rl.mu.
+func (rl *RateLimiter) consume(buckets map[string]*tokenBucket, identifier string, needed, capacity, burstCredits int) RateLimitResult {
+	now := time.Now()
 
-	bucket, exists := rl.tokenBuckets[identifier]
+	bucket, exists := buckets[identifier]
 	if !exists {
-		bucket = &tokenBucket{
-			tokens:     ratePerMinute - tokensNeeded,
-			lastRefill: time.Now(),
-			capacity:   ratePerMinute,
+		bucket = &tokenBucket{tokens: capacity, lastRefill: now, capacity: capacity}
+		buckets[identifier] = bucket
+	}
+
+	// Update capacity if rate limit changed
+	if bucket.capacity != capacity {
+		bucket.capacity = capacity
+		if bucket.tokens > capacity {
+			bucket.tokens = capacity
 		}
-		rl.tokenBuckets[identifier] = bucket
-		return true
 	}
 
-	// Refill tokens based on time elapsed
-	now := time.Now()
-	elapsed := now.Sub(bucket.lastRefill)
-	if elapsed >= time.Minute {
-		bucket.tokens = bucket.capacity
+	// Start a new window, repaying credits borrowed in the previous one
+	if elapsed := now.Sub(bucket.lastRefill); elapsed >= time.Minute {
+		owed := bucket.borrowed
+		if elapsed >= 2*time.Minute {
+			owed = 0
+		}
+		if owed > capacity {
+			owed = capacity
+		}
+		bucket.tokens = capacity - owed
+		bucket.payback = owed
+		bucket.borrowed = 0
 		bucket.lastRefill = now
 	}
 
-	// Check if enough tokens available
-	if bucket.tokens >= tokensNeeded {
-		bucket.tokens -= tokensNeeded
-		return true
+	result := RateLimitResult{}
+	switch {
+	case bucket.tokens >= needed:
+		bucket.tokens -= needed
+		result.Allowed = true
+	case bucket.payback == 0 && bucket.borrowed+needed-bucket.tokens <= burstCredits:
+		result.BurstUsed = needed - bucket.tokens
+		bucket.borrowed += result.BurstUsed
+		bucket.tokens = 0
+		result.Allowed = true
 	}
 
-	return false
+	result.Window = domain.RateLimitWindow{
+		Limit:     capacity,
+		Remaining: bucket.tokens,
+		Reset:     bucket.lastRefill.Add(time.Minute),
+		Borrowed:  bucket.borrowed,
+		Payback:   bucket.payback,
+	}
+	return result
+}
+
+// BurstCredits returns the credits borrowable per window for a per-minute limit.
+// The multiplier is capped at 2 (a window can borrow at most the whole next
+// window); maxCredits caps the result when positive.
+func BurstCredits(limit int, multiplier float64, maxCredits int) int {
+	if multiplier <= 1 || limit <= 0 {
+		return 0
+	}
+	if multiplier > 2 {
+		multiplier = 2
+	}
+	credits := int(float64(limit) * (multiplier - 1))
+	if maxCredits > 0 && credits > maxCredits {
+		credits = maxCredits
+	}
+	return credits
 }
 
 // cleanup removes old buckets periodically
@@ -7,6 +7,7 @@ import (
 	"regexp"
 	"strings"
 	"sync"
+	"time"
 	"unicode/utf8"
 
 	"modelgate/internal/domain"
@@ -25,6 +26,8 @@ type Engine struct {
 
 	// Configuration
 	config EngineConfig
+
+	now func() time.Time // Clock for time: condition keys
 }
 
 // EngineConfig contains policy engine configuration
@@ -65,6 +68,7 @@ func NewEngine(policyRepo domain.PolicyRepository, tenantRepo domain.TenantRepos
 		tenantRepo:   tenantRepo,
 		patternCache: make(map[string]*regexp.Regexp),
 		config:       config,
+		now:          time.Now,
 	}
 }
 
@@ -585,8 +589,10 @@ func (e *Engine) evaluatePolicy(policy *domain.Policy, tenant *domain.Tenant, re
 
 		// Check conditions
 		conditionsMet := true
+		now := e.now()
 		for _, condition := range statement.Conditions {
-			if !e.evaluateCondition(condition, tenant, req) {
+			value, present := conditionValue(condition.Key, tenant, req, now)
+			if !evaluateCondition(condition, value, present) {
 				conditionsMet = false
 				break
 			}
@@ -627,68 +633,6 @@ func (e *Engine) getRequestResource(req *domain.ChatRequest) string {
 	return fmt.Sprintf("arn:modelgate:model:%s", req.Model)
 }
 
-// evaluateCondition evaluates a policy condition
-func (e *Engine) evaluateCondition(condition domain.PolicyCondition, tenant *domain.Tenant, req *domain.ChatRequest) bool {
-	value := e.getConditionValue(condition.Key, tenant, req)
-
-	switch condition.Operator {
-	case "StringEquals":
-		for _, v := range condition.Values {
-			if value == v {
-				return true
-			}
-		}
-		return false
-
-	case "StringNotEquals":
-		for _, v := range condition.Values {
-			if value == v {
-				return false
-			}
-		}
-		return true
-
-	case "StringLike":
-		for _, v := range condition.Values {
-			if matchesPattern(value, v) {
-				return true
-			}
-		}
-		return false
-
-	case "NumericLessThan":
-		// Implement numeric comparison
-		return true
-
-	case "NumericGreaterThan":
-		return true
-
-	default:
-		return true
-	}
-}
-
-// getConditionValue gets the value for a condition key
-func (e *Engine) getConditionValue(key string, tenant *domain.Tenant, req *domain.ChatRequest) string {
-	switch key {
-	case "tenant:Tier":
-		return string(tenant.Tier)
-	case "tenant:Status":
-		return string(tenant.Status)
-	case "request:Model":
-		return req.Model
-	case "request:ToolCount":
-		return fmt.Sprintf("%d", len(req.Tools))
-	case "request:HasReasoning":
-		if req.ReasoningConfig != nil && req.ReasoningConfig.Enabled {
-			return "true"
-		}
-		return "false"
-	default:
-		return ""
-	}
-}
-
 // EvaluateToolCall evaluates a tool call against policies
 func (e *Engine) EvaluateToolCall(ctx context.Context, tenantID string, toolCall *domain.ToolCall) (*domain.PolicyEvaluationResult, error) {
 	result := &domain.PolicyEvaluationResult{
@@ -1042,6 +986,24 @@ func (e *Engine) extractProviderFromModel(model string) domain.Provider {
 	if strings.HasPrefix(modelLower, "ollama/") {
 		return domain.ProviderOllama
 	}
+	if strings.HasPrefix(modelLower, "openrouter/") {
+		return domain.ProviderOpenRouter
+	}
+	if strings.HasPrefix(modelLower, "xai/") {
+		return domain.ProviderXAI
+	}
+	if strings.HasPrefix(modelLower, "deepseek/") {
+		return domain.ProviderDeepSeek
+	}
+	if strings.HasPrefix(modelLower, "huggingface/") {
+		return domain.ProviderHuggingFace
+	}
+	if strings.HasPrefix(modelLower, "fireworks/") {
+		return domain.ProviderFireworks
+	}
+	if strings.HasPrefix(modelLower, "custom/") {
+		return domain.ProviderCustom
+	}
 
 	// Infer from model name patterns
 	if strings.HasPrefix(modelLower, "gpt-") || strings.HasPrefix(modelLower, "o1") || strings.HasPrefix(modelLower, "text-embedding") {
@@ -1053,6 +1015,12 @@ func (e *Engine) extractProviderFromModel(model string) domain.Provider {
 	if strings.HasPrefix(modelLower, "gemini") {
 		return domain.ProviderGemini
 	}
+	if strings.HasPrefix(modelLower, "grok") {
+		return domain.ProviderXAI
+	}
+	if strings.HasPrefix(modelLower, "deepseek-") {
+		return domain.ProviderDeepSeek
+	}
 	if strings.Contains(modelLower, "llama") || strings.Contains(modelLower, "mixtral") || strings.Contains(modelLower, "mistral") {
 		// Could be multiple providers - check more specific patterns
 		if strings.Contains(modelLower, "groq") {
@@ -0,0 +1,94 @@
+package policy
+
+import (
+	"strconv"
+
+	"modelgate/internal/domain"
+)
+
+// =============================================================================
+// Generation Parameter Policy
+// =============================================================================
+
+// ClampedParam is a client-supplied generation parameter that a role's
+// generation policy brought within its bounds
+type ClampedParam struct {
+	Name  string  // temperature, top_p or max_tokens
+	From  float64 // The client's value
+	Value float64 // The value sent to the provider
+}
+
+// String formats the parameter as name=value for the response header
+func (c ClampedParam) String() string {
+	return c.Name + "=" + formatParam(c.Value)
+}
+
+// formatParam formats a parameter at float32 precision, so 0.3 isn't shown as
+// 0.30000001192092896
+func formatParam(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 32)
+}
+
+// ApplyGenerationPolicy fills in the role's default temperature, top_p and
+// max_tokens where the request leaves them out and clamps the request's
+// values to the role's bounds. A parameter left out of a request with no
+// default but a cap is set to the cap, since the provider's own default may
+// exceed it. Returns the client values that were clamped.
+func ApplyGenerationPolicy(req *domain.ChatRequest, gp *domain.GenerationPolicy) []ClampedParam {
+	if gp == nil || !gp.Enabled {
+		return nil
+	}
+	var clamped []ClampedParam
+
+	if req.Temperature == nil {
+		if v := firstSet(gp.DefaultTemperature, gp.MaxTemperature); v != nil {
+			req.Temperature = clampFloat(*v, gp.MinTemperature, gp.MaxTemperature)
+		}
+	} else if v := clampFloat(*req.Temperature, gp.MinTemperature, gp.MaxTemperature); *v != *req.Temperature {
+		clamped = append(clamped, ClampedParam{Name: "temperature", From: float64(*req.Temperature), Value: float64(*v)})
+		req.Temperature = v
+	}
+
+	if req.TopP == nil {
+		if v := firstSet(gp.DefaultTopP, gp.MaxTopP); v != nil {
+			req.TopP = clampFloat(*v, nil, gp.MaxTopP)
+		}
+	} else if v := clampFloat(*req.TopP, nil, gp.MaxTopP); *v != *req.TopP {
+		clamped = append(clamped, ClampedParam{Name: "top_p", From: float64(*req.TopP), Value: float64(*v)})
+		req.TopP = v
+	}
+
+	if req.MaxTokens == nil {
+		v := gp.DefaultMaxTokens
+		if v <= 0 || (gp.MaxTokensLimit > 0 && v > gp.MaxTokensLimit) {
+			v = gp.MaxTokensLimit
+		}
+		if v > 0 {
+			req.MaxTokens = &v
+		}
+	} else if limit := gp.MaxTokensLimit; limit > 0 && *req.MaxTokens > limit {
+		clamped = append(clamped, ClampedParam{Name: "max_tokens", From: float64(*req.MaxTokens), Value: float64(limit)})
+		req.MaxTokens = &limit
+	}
+	return clamped
+}
+
+func firstSet(values ...*float32) *float32 {
+	for _, v := range values {
+		if v != nil {
+			return v
+		}
+	}
+	return nil
+}
+
+// clampFloat returns a pointer to v within the optional bounds
+func clampFloat(v float32, lo, hi *float32) *float32 {
+	if hi != nil && v > *hi {
+		v = *hi
+	}
+	if lo != nil && v < *lo {
+		v = *lo
+	}
+	return &v
+}
@@ -0,0 +1,97 @@
+package policy
+
+import (
+	"context"
+	"testing"
+
+	"modelgate/internal/domain"
+)
+
+func float32Ptr(v float32) *float32 { return &v }
+
+func int32Ptr(v int32) *int32 { return &v }
+
+func TestApplyGenerationPolicy(t *testing.T) {
+	compliance := &domain.GenerationPolicy{
+		Enabled:            true,
+		DefaultTemperature: float32Ptr(0.1),
+		MaxTemperature:     float32Ptr(0.3),
+		MaxTopP:            float32Ptr(0.9),
+		DefaultMaxTokens:   512,
+		MaxTokensLimit:     1024,
+	}
+
+	// Defaults fill in what the request leaves out; a cap without a default
+	// is used as the value
+	req := &domain.ChatRequest{}
+	if clamped := ApplyGenerationPolicy(req, compliance); len(clamped) != 0 {
+		t.Fatalf("expected defaults not to count as clamping, got %v", clamped)
+	}
+	if *req.Temperature != 0.1 || *req.TopP != 0.9 || *req.MaxTokens != 512 {
+		t.Fatalf("unexpected defaults temperature=%v top_p=%v max_tokens=%v", *req.Temperature, *req.TopP, *req.MaxTokens)
+	}
+
+	// Client values within bounds are kept, the others clamped
+	req = &domain.ChatRequest{Temperature: float32Ptr(0.9), TopP: float32Ptr(0.5), MaxTokens: int32Ptr(4096)}
+	clamped := ApplyGenerationPolicy(req, compliance)
+	if len(clamped) != 2 || clamped[0].String() != "temperature=0.3" || clamped[1].String() != "max_tokens=1024" {
+		t.Fatalf("unexpected clamped params %v", clamped)
+	}
+	if *req.Temperature != 0.3 || *req.TopP != 0.5 || *req.MaxTokens != 1024 {
+		t.Fatalf("unexpected params temperature=%v top_p=%v max_tokens=%v", *req.Temperature, *req.TopP, *req.MaxTokens)
+	}
+
+	// A minimum raises low values but leaves absent ones to the provider
+	creative := &domain.GenerationPolicy{Enabled: true, MinTemperature: float32Ptr(0.7)}
+	req = &domain.ChatRequest{Temperature: float32Ptr(0)}
+	if clamped := ApplyGenerationPolicy(req, creative); len(clamped) != 1 || *req.Temperature != 0.7 {
+		t.Fatalf("expected temperature raised to 0.7, got %v", clamped)
+	}
+	req = &domain.ChatRequest{}
+	if ApplyGenerationPolicy(req, creative); req.Temperature != nil {
+		t.Fatalf("expected no temperature, got %v", *req.Temperature)
+	}
+
+	// Disabled policies change nothing
+	req = &domain.ChatRequest{Temperature: float32Ptr(1.5)}
+	if clamped := ApplyGenerationPolicy(req, &domain.GenerationPolicy{MaxTemperature: float32Ptr(0.3)}); len(clamped) != 0 || *req.Temperature != 1.5 {
+		t.Fatal("expected a disabled policy to be ignored")
+	}
+
+	for _, invalid := range []domain.GenerationPolicy{
+		{MaxTemperature: float32Ptr(2.5)},
+		{DefaultTopP: float32Ptr(-0.1)},
+		{MinTemperature: float32Ptr(0.8), MaxTemperature: float32Ptr(0.2)},
+		{MaxTokensLimit: -1},
+	} {
+		if err := invalid.Validate(); err == nil {
+			t.Fatalf("expected %+v to be invalid", invalid)
+		}
+	}
+	if err := compliance.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Dry runs report what would be clamped
+	rolePolicy := domain.DefaultRolePolicy("role-1", "compliance")
+	rolePolicy.GenerationPolicy = *compliance
+	store := &dryRunStore{role: &domain.Role{ID: "role-1", Name: "compliance", Policy: rolePolicy}}
+	result, err := NewEnforcementService().DryRun(context.Background(), store, &domain.ChatRequest{
+		Model:       "gpt-4o",
+		RoleID:      "role-1",
+		Temperature: float32Ptr(0.9),
+		Messages:    []domain.Message{{Role: "user", Content: []domain.ContentBlock{{Type: "text", Text: "hello"}}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range result.Checks {
+		if c.Policy == "generation_policy" {
+			if c.Outcome != DryRunModify || c.Message != "Generation parameters would be clamped: temperature 0.9 to 0.3" {
+				t.Fatalf("unexpected generation check %+v", c)
+			}
+			return
+		}
+	}
+	t.Fatal("expected a generation_policy check")
+}
@@ -0,0 +1,131 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"modelgate/internal/domain"
+)
+
+// =============================================================================
+// Embedding Similarity Injection Detection
+// =============================================================================
+
+// defaultSimilarityThreshold is the similarity to a known attack that counts
+// as a detection when the role's ML detection sets no threshold
+const defaultSimilarityThreshold = 0.85
+
+// InjectionMatcher finds the known attack most similar to a text
+// (implemented by injection.Service)
+type InjectionMatcher interface {
+	Match(ctx context.Context, text string) (*domain.InjectionMatch, error)
+}
+
+// SetInjectionMatcher enables ML injection detection by similarity to the
+// injection corpus
+func (s *EnforcementService) SetInjectionMatcher(m InjectionMatcher) {
+	s.injectionMatcher = m
+}
+
+// usesCorpus reports whether ML detection is on with the gateway's own
+// embedding model rather than an external classifier
+func usesCorpus(cfg domain.MLDetectionConfig) bool {
+	if !cfg.Enabled {
+		return false
+	}
+	switch strings.ToLower(cfg.Model) {
+	case "", "builtin", "embedding":
+		return true
+	}
+	return false
+}
+
+// validateInjectionSimilarity compares the latest user message, and for
+// indirect detection the tool results that followed it, with the injection
+// corpus. Matches at or above the role's threshold for their category block
+// the request, or are flagged in enfCtx.Flags when the role only warns or
+// logs. When the corpus can't be searched the request is allowed.
+func (s *EnforcementService) validateInjectionSimilarity(ctx context.Context, enfCtx *EnforcementContext) error {
+	if s.injectionMatcher == nil {
+		return nil
+	}
+	pp := &enfCtx.Policy.PromptPolicies
+
+	lastUser := -1
+	for i := len(enfCtx.Messages) - 1; i >= 0; i-- {
+		if enfCtx.Messages[i].Role == "user" {
+			lastUser = i
+			break
+		}
+	}
+
+	direct := pp.DirectInjectionDetection
+	if direct.Enabled && usesCorpus(direct.MLDetection) && lastUser >= 0 {
+		text := s.extractMessageText(enfCtx.Messages[lastUser])
+		if err := s.checkSimilarity(ctx, enfCtx, direct, text, "injection_detected", "prompt"); err != nil {
+			return err
+		}
+	}
+
+	indirect := pp.IndirectInjectionDetection
+	if indirect.Enabled && usesCorpus(indirect.MLDetection) {
+		var results strings.Builder
+		for _, msg := range enfCtx.Messages[lastUser+1:] {
+			if msg.Role == "tool" {
+				results.WriteString(s.extractMessageText(msg))
+				results.WriteString("\n")
+			}
+		}
+		if err := s.checkSimilarity(ctx, enfCtx, indirect, results.String(), "indirect_injection_detected", "tool result"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *EnforcementService) checkSimilarity(ctx context.Context, enfCtx *EnforcementContext, cfg domain.InjectionDetectionConfig, text, code, source string) error {
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+	match, err := s.injectionMatcher.Match(ctx, text)
+	if err != nil {
+		slog.Warn("Injection similarity check failed, allowing request", "role_id", enfCtx.RoleID, "error", err)
+		return nil
+	}
+	if match == nil || match.Similarity < similarityThreshold(cfg.MLDetection, match.Pattern.Category) {
+		return nil
+	}
+
+	outlier := match.Outlier()
+	violation := &PolicyViolation{
+		Code:    code,
+		Message: fmt.Sprintf("Potential %s detected in the %s", match.Pattern.Category, source),
+		Type:    "prompt",
+		Outlier: &outlier,
+	}
+	switch cfg.OnDetection {
+	case "", domain.DetectionActionBlock, "BLOCK", domain.DetectionActionQuarantine:
+		slog.Info("Blocking request due to injection similarity", "role_id", enfCtx.RoleID, "similarity", match.Similarity,
+			"category", match.Pattern.Category, "pattern_id", match.Pattern.ID)
+		return violation
+	}
+	slog.Warn("Injection similarity flagged but not blocked", "role_id", enfCtx.RoleID, "similarity", match.Similarity,
+		"category", match.Pattern.Category, "pattern_id", match.Pattern.ID, "action", cfg.OnDetection)
+	violation.Code = strings.TrimSuffix(code, "_detected") + "_flagged"
+	enfCtx.Flags = append(enfCtx.Flags, violation)
+	return nil
+}
+
+// similarityThreshold returns the role's threshold for a category of attack
+func similarityThreshold(cfg domain.MLDetectionConfig, category domain.InjectionCategory) float64 {
+	threshold := cfg.InjectionThreshold
+	if category == domain.InjectionCategoryJailbreak {
+		threshold = cfg.JailbreakThreshold
+	}
+	if threshold <= 0 || threshold > 1 {
+		return defaultSimilarityThreshold
+	}
+	return threshold
+}
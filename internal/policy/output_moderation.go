@@ -0,0 +1,225 @@
+package policy
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"modelgate/internal/domain"
+)
+
+// =============================================================================
+// Streaming Output Moderation
+// =============================================================================
+
+// defaultStreamWindowChars is how much streamed text is held back when the
+// policy doesn't set a window. It bounds the longest match that is caught
+// before any of it reaches the client.
+const defaultStreamWindowChars = 128
+
+// secretPatterns detects common credential formats in generated output
+var secretPatterns = map[string]*regexp.Regexp{
+	"aws_access_key":  regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),
+	"private_key":     regexp.MustCompile(`-----BEGIN (?:[A-Z]+ )?PRIVATE KEY-----`),
+	"api_key":         regexp.MustCompile(`\bsk-(?:proj-|ant-)?[A-Za-z0-9_-]{20,}`),
+	"github_token":    regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}`),
+	"slack_token":     regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`),
+	"google_api_key":  regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}`),
+	"jwt":             regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`),
+	"password_assign": regexp.MustCompile(`(?i)\b(?:password|passwd|secret|api_key|apikey)\s*[:=]\s*["']?[^\s"']{8,}`),
+}
+
+// Dangerous content patterns, one per OutputValidationConfig detector
+var (
+	codeExecutionPattern = regexp.MustCompile(`(?i)\b(?:eval|exec)\s*\(|\bos\.system\s*\(|\bsubprocess\.(?:run|Popen|call|check_output)\s*\(|Runtime\.getRuntime\(\)\.exec`)
+	sqlStatementPattern  = regexp.MustCompile(`(?i)\b(?:DROP\s+(?:TABLE|DATABASE)|DELETE\s+FROM|TRUNCATE\s+TABLE|ALTER\s+TABLE|INSERT\s+INTO|UPDATE\s+\w+\s+SET)\b`)
+	shellCommandPattern  = regexp.MustCompile(`(?i)\brm\s+-rf\s+/|\b(?:curl|wget)\s+[^|\n]*\|\s*(?:ba|z)?sh\b|\bchmod\s+777\b|\bmkfs\.\w+|:\(\)\s*\{\s*:\|:&\s*\};:`)
+	htmlScriptPattern    = regexp.MustCompile(`(?i)<script\b|javascript:|\bon(?:error|load|click)\s*=`)
+)
+
+// outputScanner checks a window of generated text and returns what matched
+type outputScanner struct {
+	name  string
+	match func(text string) (detail string, ok bool)
+}
+
+// StreamModerator runs output scanners over a streamed response. Text is held
+// back until it has been scanned together with the text around it, so content
+// split across chunks is caught before any of it is released.
+type StreamModerator struct {
+	scanners []outputScanner
+	action   domain.OutputViolationAction
+	window   int
+
+	released string // Tail of the text already released, rescanned with new chunks
+	pending  string // Scanned text not yet released
+	flagged  bool   // A non-blocking violation was already reported
+}
+
+// NewStreamModerator builds a moderator from a role's prompt policies. It
+// returns nil when output validation is off or no streaming scanner is enabled.
+func NewStreamModerator(pp *domain.PromptPolicies, systemPrompt string) *StreamModerator {
+	if pp == nil || !pp.OutputValidation.Enabled {
+		return nil
+	}
+	cfg := pp.OutputValidation
+
+	m := &StreamModerator{
+		action: cfg.OnViolation,
+		window: cfg.StreamWindowChars,
+	}
+	if m.window <= 0 {
+		m.window = defaultStreamWindowChars
+	}
+	if m.action == "" {
+		m.action = domain.OutputActionBlock
+	}
+
+	if cfg.DetectSecretLeakage {
+		patterns := make(map[string]*regexp.Regexp, len(secretPatterns)+len(cfg.SecretPatterns))
+		for name, re := range secretPatterns {
+			patterns[name] = re
+		}
+		for _, p := range cfg.SecretPatterns {
+			re, err := regexp.Compile(p)
+			if err != nil {
+				slog.Warn("Ignoring invalid secret pattern", "pattern", p, "error", err)
+				continue
+			}
+			patterns[p] = re
+		}
+		m.scanners = append(m.scanners, outputScanner{"secret", matchAny(patterns)})
+	}
+	if cfg.DetectPIILeakage {
+		categories := pp.PIIPolicy.Categories
+		m.scanners = append(m.scanners, outputScanner{"pii", func(text string) (string, bool) {
+			category := (&EnforcementService{}).detectPII(text, categories)
+			return category, category != ""
+		}})
+	}
+	if cfg.DetectCodeExecution {
+		m.scanners = append(m.scanners, outputScanner{"code_execution", matchPattern(codeExecutionPattern)})
+	}
+	if cfg.DetectSQLStatements {
+		m.scanners = append(m.scanners, outputScanner{"sql", matchPattern(sqlStatementPattern)})
+	}
+	if cfg.DetectShellCommands {
+		m.scanners = append(m.scanners, outputScanner{"shell", matchPattern(shellCommandPattern)})
+	}
+	if cfg.DetectHTMLScripts {
+		m.scanners = append(m.scanners, outputScanner{"html_script", matchPattern(htmlScriptPattern)})
+	}
+	if cfg.DetectSystemPromptLeakage && strings.TrimSpace(systemPrompt) != "" {
+		m.scanners = append(m.scanners, outputScanner{"system_prompt", matchSystemPrompt(systemPrompt, m.window)})
+	}
+
+	if len(m.scanners) == 0 {
+		return nil
+	}
+	return m
+}
+
+// Push adds a streamed text chunk and returns the text that is safe to send
+// now. A non-nil violation with Truncated set means the stream must end here;
+// nothing from the offending window is released.
+func (m *StreamModerator) Push(chunk string) (string, *domain.OutputViolation) {
+	m.pending += chunk
+
+	if v := m.scan(m.released + m.pending); v != nil {
+		if v.Truncated {
+			m.pending = ""
+			return "", v
+		}
+		if !m.flagged {
+			m.flagged = true
+			return m.release(), v
+		}
+	}
+	return m.release(), nil
+}
+
+// Flush releases all held-back text, which has already been scanned. Call it
+// before forwarding a non-text event so output keeps its order.
+func (m *StreamModerator) Flush() string {
+	out := m.pending
+	m.pending = ""
+	m.released = tail(m.released+out, m.window)
+	return out
+}
+
+// release returns pending text beyond the held-back window
+func (m *StreamModerator) release() string {
+	if len(m.pending) <= m.window {
+		return ""
+	}
+	cut := len(m.pending) - m.window
+	for cut > 0 && !utf8.RuneStart(m.pending[cut]) {
+		cut--
+	}
+	out := m.pending[:cut]
+	m.pending = m.pending[cut:]
+	m.released = tail(m.released+out, m.window)
+	return out
+}
+
+func (m *StreamModerator) scan(text string) *domain.OutputViolation {
+	for _, s := range m.scanners {
+		if detail, ok := s.match(text); ok {
+			truncate := m.action != domain.OutputActionWarn && m.action != domain.OutputActionLog
+			return &domain.OutputViolation{Scanner: s.name, Detail: detail, Action: m.action, Truncated: truncate}
+		}
+	}
+	return nil
+}
+
+func matchPattern(re *regexp.Regexp) func(string) (string, bool) {
+	return func(text string) (string, bool) {
+		return "", re.MatchString(text)
+	}
+}
+
+func matchAny(patterns map[string]*regexp.Regexp) func(string) (string, bool) {
+	return func(text string) (string, bool) {
+		for name, re := range patterns {
+			if re.MatchString(text) {
+				return name, true
+			}
+		}
+		return "", false
+	}
+}
+
+// matchSystemPrompt reports output that repeats a run of the system prompt.
+// Fragments are short enough to fit in the scan window.
+func matchSystemPrompt(systemPrompt string, window int) func(string) (string, bool) {
+	normalized := strings.Join(strings.Fields(strings.ToLower(systemPrompt)), " ")
+	size := min(min(48, window/2), len(normalized))
+	var fragments []string
+	if size >= 20 { // Shorter runs match ordinary prose too often
+		for i := 0; i+size <= len(normalized); i += size / 2 {
+			fragments = append(fragments, normalized[i:i+size])
+		}
+	}
+	return func(text string) (string, bool) {
+		text = strings.Join(strings.Fields(strings.ToLower(text)), " ")
+		for _, f := range fragments {
+			if strings.Contains(text, f) {
+				return "", true
+			}
+		}
+		return "", false
+	}
+}
+
+// tail returns at most the last n bytes of s, starting on a rune boundary
+func tail(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	cut := len(s) - n
+	for cut < len(s) && !utf8.RuneStart(s[cut]) {
+		cut++
+	}
+	return s[cut:]
+}
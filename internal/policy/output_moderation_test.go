@@ -0,0 +1,49 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+
+	"modelgate/internal/domain"
+)
+
+func TestStreamModeratorCatchesSecretSplitAcrossChunks(t *testing.T) {
+	pp := &domain.PromptPolicies{OutputValidation: domain.OutputValidationConfig{
+		Enabled:             true,
+		DetectSecretLeakage: true,
+		StreamWindowChars:   32,
+	}}
+	m := NewStreamModerator(pp, "")
+
+	var sent strings.Builder
+	chunks := []string{"Here is some harmless text that goes on for a while. ", "Your key is AKIA", "IOSFODNN7EXAMPLE and more"}
+	var violation *domain.OutputViolation
+	for _, c := range chunks {
+		out, v := m.Push(c)
+		sent.WriteString(out)
+		if v != nil {
+			violation = v
+			break
+		}
+	}
+
+	if violation == nil || violation.Scanner != "secret" || !violation.Truncated {
+		t.Fatalf("expected truncating secret violation, got %+v", violation)
+	}
+	if strings.Contains(sent.String(), "AKIA") {
+		t.Fatalf("secret prefix was released before detection: %q", sent.String())
+	}
+	if !strings.HasPrefix(chunks[0], sent.String()) || sent.Len() == 0 {
+		t.Fatalf("expected text before the window to be released, got %q", sent.String())
+	}
+
+	clean := NewStreamModerator(pp, "")
+	out, v := clean.Push("nothing to see here")
+	if v != nil || out != "" || clean.Flush() != "nothing to see here" {
+		t.Fatalf("expected short clean text to be held until flush, got %q %+v", out, v)
+	}
+
+	if NewStreamModerator(&domain.PromptPolicies{}, "") != nil {
+		t.Fatal("expected no moderator when output validation is disabled")
+	}
+}
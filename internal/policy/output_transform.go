@@ -0,0 +1,340 @@
+package policy
+
+import (
+	"log/slog"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"modelgate/internal/domain"
+)
+
+// =============================================================================
+// Output Transformation
+// =============================================================================
+
+// maxHeldLine is how much of a streamed line without a line break is held
+// back before it's transformed anyway
+const maxHeldLine = 4096
+
+var (
+	reasoningOpen  = regexp.MustCompile(`<think(?:ing)?>`)
+	reasoningClose = regexp.MustCompile(`</think(?:ing)?>`)
+
+	citationMarker     = regexp.MustCompile(`[ \t]?(?:\[\^?\d+(?:\s*[,–-]\s*\d+)*\]|【[^】]*】)`)
+	footnoteDefinition = regexp.MustCompile(`^\s*\[\^\d+\]:`)
+
+	mdFence        = regexp.MustCompile(`^\s{0,3}(?:` + "```" + `|~~~)`)
+	mdHeading      = regexp.MustCompile(`^\s{0,3}#{1,6}\s+(.*?)(?:\s+#+)?\s*$`)
+	mdRule         = regexp.MustCompile(`^\s{0,3}(?:(?:-\s*){3,}|(?:\*\s*){3,}|(?:_\s*){3,}|=+\s*)$`)
+	mdQuote        = regexp.MustCompile(`^\s{0,3}(?:>\s?)+`)
+	mdBullet       = regexp.MustCompile(`^(\s*)[-*+]\s+`)
+	mdTableDivider = regexp.MustCompile(`^\s*\|?\s*:?-{3,}:?\s*(?:\|\s*:?-{3,}:?\s*)*\|?\s*$`)
+	mdCodeSpan     = regexp.MustCompile("`+[^`]+`+")
+	mdInline       = []struct {
+		re   *regexp.Regexp
+		repl string
+	}{
+		{regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`), "$1"},
+		{regexp.MustCompile(`\[([^\]]+)\]\([^)]*\)`), "$1"},
+		{regexp.MustCompile(`<((?:https?|mailto):[^>\s]+)>`), "$1"},
+		{regexp.MustCompile(`\*\*(\S(?:.*?\S)?)\*\*`), "$1"},
+		{regexp.MustCompile(`(^|\W)__(\S(?:.*?\S)?)__(\W|$)`), "$1$2$3"},
+		{regexp.MustCompile(`\*(\S(?:[^*]*?\S)?)\*`), "$1"},
+		{regexp.MustCompile(`(^|\W)_(\S(?:[^_]*?\S)?)_(\W|$)`), "$1$2$3"},
+		{regexp.MustCompile(`~~(\S(?:.*?\S)?)~~`), "$1"},
+		{regexp.MustCompile(`\\([\\` + "`" + `*_{}\[\]()#+\-.!>|])`), "$1"},
+	}
+)
+
+type compiledRewrite struct {
+	name string
+	re   *regexp.Regexp
+	repl string
+}
+
+// OutputTransformer applies a role's output transform policy to response
+// text. Streamed text is transformed a line at a time: a partial line is held
+// back until its line break arrives, or until it grows past maxHeldLine.
+type OutputTransformer struct {
+	policy   *domain.OutputTransformPolicy
+	rewrites []compiledRewrite
+
+	pending  string // Start of a line not yet transformed
+	midLine  bool   // The pending text continues a line already partly released
+	inFence  bool   // Inside a fenced code block
+	inThink  bool   // Inside a reasoning block
+	done     bool   // MaxLength was reached; the rest is dropped
+	applied  []string
+	original int // Characters received
+	chars    int // Characters released
+}
+
+// NewOutputTransformer builds a transformer from a role's policy. It returns
+// nil when the policy is off or changes nothing.
+func NewOutputTransformer(p *domain.OutputTransformPolicy) *OutputTransformer {
+	if p == nil || !p.Enabled {
+		return nil
+	}
+	t := &OutputTransformer{policy: p}
+	for i, r := range p.Rewrites {
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			slog.Warn("Ignoring invalid output rewrite pattern", "pattern", r.Pattern, "error", err)
+			continue
+		}
+		name := r.Name
+		if name == "" {
+			name = strconv.Itoa(i + 1)
+		}
+		t.rewrites = append(t.rewrites, compiledRewrite{name: "rewrite:" + name, re: re, repl: r.Replacement})
+	}
+	if !p.StripReasoning && !p.StripCitations && !p.StripMarkdown && len(t.rewrites) == 0 && p.MaxLength <= 0 {
+		return nil
+	}
+	return t
+}
+
+// DropsThinking reports whether reasoning returned apart from the text
+// (thinking events and the response's thinking) is dropped, noting it if so
+func (t *OutputTransformer) DropsThinking() bool {
+	if t.policy.StripReasoning {
+		t.note("reasoning")
+	}
+	return t.policy.StripReasoning
+}
+
+// DropsCitations reports whether citations returned with the text are
+// dropped, noting it if so
+func (t *OutputTransformer) DropsCitations() bool {
+	if t.policy.StripCitations {
+		t.note("citations")
+	}
+	return t.policy.StripCitations
+}
+
+// Transform transforms a whole response's text
+func (t *OutputTransformer) Transform(text string) string {
+	return t.Push(text) + t.Flush()
+}
+
+// Push adds a streamed text chunk and returns the transformed text of the
+// lines it completed
+func (t *OutputTransformer) Push(chunk string) string {
+	t.original += utf8.RuneCountInString(chunk)
+	t.pending += chunk
+
+	var out strings.Builder
+	for {
+		i := strings.IndexByte(t.pending, '\n')
+		if i < 0 {
+			break
+		}
+		line := t.pending[:i+1]
+		t.pending = t.pending[i+1:]
+		out.WriteString(t.line(line))
+		t.midLine = false
+	}
+	if len(t.pending) > maxHeldLine {
+		// Keep the last, possibly incomplete, rune
+		cut := len(t.pending) - 1
+		for cut > 0 && !utf8.RuneStart(t.pending[cut]) {
+			cut--
+		}
+		segment := t.pending[:cut]
+		t.pending = t.pending[cut:]
+		out.WriteString(t.line(segment))
+		t.midLine = true
+	}
+	return out.String()
+}
+
+// Flush transforms and returns held-back text. Call it before forwarding a
+// non-text event so output keeps its order.
+func (t *OutputTransformer) Flush() string {
+	if t.pending == "" {
+		return ""
+	}
+	segment := t.pending
+	t.pending = ""
+	out := t.line(segment)
+	t.midLine = true
+	return out
+}
+
+// Result describes what the transformer changed, or nil if nothing
+func (t *OutputTransformer) Result() *domain.OutputTransform {
+	if len(t.applied) == 0 {
+		return nil
+	}
+	return &domain.OutputTransform{
+		Applied:       slices.Clone(t.applied),
+		OriginalChars: t.original,
+		Chars:         t.chars,
+	}
+}
+
+// note records a transformation that changed the text
+func (t *OutputTransformer) note(name string) {
+	if !slices.Contains(t.applied, name) {
+		t.applied = append(t.applied, name)
+	}
+}
+
+// line transforms a line, or the part of one held back too long. An empty
+// result drops the line with its line break.
+func (t *OutputTransformer) line(segment string) string {
+	if t.done {
+		return ""
+	}
+	body, newline := strings.CutSuffix(segment, "\n")
+	lineStart := !t.midLine
+
+	if t.policy.StripReasoning {
+		stripped := t.stripReasoning(body)
+		if stripped != body {
+			t.note("reasoning")
+			if strings.TrimSpace(stripped) == "" {
+				return ""
+			}
+			body = stripped
+		}
+	}
+
+	// Code blocks are tracked whether or not markdown is stripped, so that
+	// citations aren't stripped from code
+	fence := lineStart && mdFence.MatchString(body)
+	code := t.inFence
+	if fence {
+		t.inFence = !t.inFence
+	}
+
+	if t.policy.StripCitations && !fence && !code {
+		if lineStart && footnoteDefinition.MatchString(body) {
+			t.note("citations")
+			return ""
+		}
+		if stripped := citationMarker.ReplaceAllString(body, ""); stripped != body {
+			t.note("citations")
+			body = stripped
+		}
+	}
+
+	if t.policy.StripMarkdown {
+		if fence {
+			t.note("markdown")
+			return ""
+		}
+		if !code {
+			stripped, keep := stripMarkdownLine(body, lineStart)
+			if !keep {
+				t.note("markdown")
+				return ""
+			}
+			if stripped != body {
+				t.note("markdown")
+				body = stripped
+			}
+		}
+	}
+
+	for _, r := range t.rewrites {
+		if rewritten := r.re.ReplaceAllString(body, r.repl); rewritten != body {
+			t.note(r.name)
+			body = rewritten
+		}
+	}
+
+	out := body
+	if newline {
+		out += "\n"
+	}
+	return t.limit(out)
+}
+
+// stripReasoning drops the text of reasoning blocks, which may span lines
+func (t *OutputTransformer) stripReasoning(text string) string {
+	var out strings.Builder
+	for text != "" {
+		if t.inThink {
+			loc := reasoningClose.FindStringIndex(text)
+			if loc == nil {
+				return out.String()
+			}
+			text = text[loc[1]:]
+			t.inThink = false
+			continue
+		}
+		loc := reasoningOpen.FindStringIndex(text)
+		if loc == nil {
+			out.WriteString(text)
+			break
+		}
+		out.WriteString(text[:loc[0]])
+		text = text[loc[1]:]
+		t.inThink = true
+	}
+	return out.String()
+}
+
+// limit cuts the text off at the policy's maximum length
+func (t *OutputTransformer) limit(text string) string {
+	n := utf8.RuneCountInString(text)
+	if t.policy.MaxLength <= 0 || t.chars+n <= t.policy.MaxLength {
+		t.chars += n
+		return text
+	}
+	keep := t.policy.MaxLength - t.chars
+	cut := 0
+	for i := 0; i < keep; i++ {
+		_, size := utf8.DecodeRuneInString(text[cut:])
+		cut += size
+	}
+	t.chars += keep
+	t.done = true
+	t.note("truncate")
+	return text[:cut] + t.policy.TruncationSuffix
+}
+
+// stripMarkdownLine converts a line of markdown outside code blocks to plain
+// text. It returns false for lines that are only markup, such as rules and
+// table dividers.
+func stripMarkdownLine(line string, lineStart bool) (string, bool) {
+	if lineStart {
+		if mdRule.MatchString(line) || mdTableDivider.MatchString(line) {
+			return "", false
+		}
+		if m := mdHeading.FindStringSubmatch(line); m != nil {
+			line = m[1]
+		}
+		line = mdQuote.ReplaceAllString(line, "")
+		line = mdBullet.ReplaceAllString(line, "$1- ")
+		if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, "|") && strings.HasSuffix(trimmed, "|") && len(trimmed) > 1 {
+			cells := strings.Split(trimmed[1:len(trimmed)-1], "|")
+			for i, c := range cells {
+				cells[i] = strings.TrimSpace(c)
+			}
+			line = strings.Join(cells, "\t")
+		}
+	}
+
+	// Code spans keep their content as written
+	var out strings.Builder
+	last := 0
+	for _, loc := range mdCodeSpan.FindAllStringIndex(line, -1) {
+		out.WriteString(stripInline(line[last:loc[0]]))
+		out.WriteString(strings.Trim(line[loc[0]:loc[1]], "`"))
+		last = loc[1]
+	}
+	out.WriteString(stripInline(line[last:]))
+	return out.String(), true
+}
+
+func stripInline(text string) string {
+	for _, r := range mdInline {
+		text = r.re.ReplaceAllString(text, r.repl)
+	}
+	return text
+}
@@ -0,0 +1,95 @@
+package policy
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"modelgate/internal/domain"
+)
+
+const transformInput = "<think>The user wants a summary.\nKeep it short.</think>\n" +
+	"## Summary\n" +
+	"This is **important** and _clear_ [1]. See [the docs](https://wiki.internal/x).\n" +
+	"\n" +
+	"* first item\n" +
+	"---\n" +
+	"| a | b |\n" +
+	"|---|---|\n" +
+	"| 1 | 2 |\n" +
+	"```go\n" +
+	"x := arr[1] * 2\n" +
+	"```\n" +
+	"Internal host: build.corp.example.com【4†source】\n" +
+	"[^1]: A footnote\n"
+
+func TestOutputTransformer(t *testing.T) {
+	p := &domain.OutputTransformPolicy{
+		Enabled:        true,
+		StripReasoning: true,
+		StripCitations: true,
+		StripMarkdown:  true,
+		Rewrites: []domain.OutputRewriteRule{
+			{Name: "hosts", Pattern: `[\w.-]+\.corp\.example\.com`, Replacement: "[internal]"},
+		},
+	}
+	want := "Summary\n" +
+		"This is important and clear. See the docs.\n" +
+		"\n" +
+		"- first item\n" +
+		"a\tb\n" +
+		"1\t2\n" +
+		"x := arr[1] * 2\n" +
+		"Internal host: [internal]\n"
+
+	whole := NewOutputTransformer(p)
+	if got := whole.Transform(transformInput); got != want {
+		t.Fatalf("unexpected transform:\n%s\nwant:\n%s", got, want)
+	}
+	result := whole.Result()
+	if result == nil || !slices.Equal(result.Applied, []string{"reasoning", "markdown", "citations", "rewrite:hosts"}) {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	// Streamed a few characters at a time, the text comes out the same
+	streamed := NewOutputTransformer(p)
+	var out strings.Builder
+	for i := 0; i < len(transformInput); i += 7 {
+		out.WriteString(streamed.Push(transformInput[i:min(i+7, len(transformInput))]))
+	}
+	out.WriteString(streamed.Flush())
+	if out.String() != want {
+		t.Fatalf("streamed transform differs:\n%s", out.String())
+	}
+}
+
+func TestOutputTransformerMaxLength(t *testing.T) {
+	p := &domain.OutputTransformPolicy{Enabled: true, MaxLength: 10, TruncationSuffix: "…"}
+	tr := NewOutputTransformer(p)
+	got := tr.Push("héllo ") + tr.Push("wörld and more\nnext line\n") + tr.Flush()
+	if got != "héllo wörl…" {
+		t.Fatalf("got %q", got)
+	}
+	if r := tr.Result(); r == nil || r.Chars != 10 || r.OriginalChars != 31 || !slices.Contains(r.Applied, "truncate") {
+		t.Fatalf("unexpected result %+v", r)
+	}
+
+	// A long line without breaks is released once it outgrows the hold
+	long := NewOutputTransformer(&domain.OutputTransformPolicy{Enabled: true, StripCitations: true})
+	if out := long.Push(strings.Repeat("ab [2] ", 1000)); len(out) == 0 || strings.Contains(out, "[2]") {
+		t.Fatalf("expected the held line to be released without markers, got %d bytes", len(out))
+	}
+}
+
+func TestOutputTransformerOff(t *testing.T) {
+	if NewOutputTransformer(&domain.OutputTransformPolicy{Enabled: true}) != nil {
+		t.Fatal("expected no transformer for a policy that changes nothing")
+	}
+	if NewOutputTransformer(&domain.OutputTransformPolicy{StripMarkdown: true}) != nil {
+		t.Fatal("expected no transformer for a disabled policy")
+	}
+	tr := NewOutputTransformer(&domain.OutputTransformPolicy{Enabled: true, StripMarkdown: true})
+	if got := tr.Transform("plain text\n"); got != "plain text\n" || tr.Result() != nil {
+		t.Fatalf("expected plain text untouched, got %q %+v", got, tr.Result())
+	}
+}
@@ -0,0 +1,109 @@
+package policy
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+func TestBurstCreditsBorrowAndPayback(t *testing.T) {
+	rl := &RateLimiter{
+		requestBuckets: make(map[string]*tokenBucket),
+		tokenBuckets:   make(map[string]*tokenBucket),
+	}
+	credits := BurstCredits(4, 1.5, 0) // 2 extra requests per window
+
+	for i := 0; i < 4; i++ {
+		if r := rl.ConsumeRequest("key", 4, credits); !r.Allowed || r.BurstUsed != 0 {
+			t.Fatalf("request %d: %+v, want allowed without burst", i, r)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if r := rl.ConsumeRequest("key", 4, credits); !r.Allowed || r.BurstUsed != 1 {
+			t.Fatalf("burst request %d: %+v, want allowed with 1 credit", i, r)
+		}
+	}
+	if r := rl.ConsumeRequest("key", 4, credits); r.Allowed || r.Window.Borrowed != 2 {
+		t.Fatalf("request beyond burst: %+v, want rejected with 2 borrowed", r)
+	}
+
+	// The next window starts with the borrowed credits already spent and cannot borrow again
+	rl.requestBuckets["key"].lastRefill = time.Now().Add(-time.Minute)
+	for i := 0; i < 2; i++ {
+		if r := rl.ConsumeRequest("key", 4, credits); !r.Allowed || r.Window.Payback != 2 {
+			t.Fatalf("payback window request %d: %+v, want allowed with payback 2", i, r)
+		}
+	}
+	if r := rl.ConsumeRequest("key", 4, credits); r.Allowed {
+		t.Fatalf("borrowing during payback: %+v, want rejected", r)
+	}
+
+	// An idle window repays the debt
+	rl.requestBuckets["key"].borrowed = 2
+	rl.requestBuckets["key"].lastRefill = time.Now().Add(-2 * time.Minute)
+	if r := rl.ConsumeRequest("key", 4, credits); !r.Allowed || r.Window.Remaining != 3 || r.Window.Payback != 0 {
+		t.Fatalf("after idle window: %+v, want full window", r)
+	}
+}
+
+func TestBurstCredits(t *testing.T) {
+	cases := []struct {
+		limit      int
+		multiplier float64
+		max        int
+		want       int
+	}{
+		{60, 0, 0, 0},
+		{60, 1, 0, 0},
+		{60, 1.5, 0, 30},
+		{60, 3, 0, 60}, // capped at one extra window
+		{60, 1.5, 10, 10},
+	}
+	for _, c := range cases {
+		if got := BurstCredits(c.limit, c.multiplier, c.max); got != c.want {
+			t.Errorf("BurstCredits(%d, %v, %d) = %d, want %d", c.limit, c.multiplier, c.max, got, c.want)
+		}
+	}
+}
+
+func TestRateLimitStatusAndRejection(t *testing.T) {
+	s := NewEnforcementService()
+	limits := domain.RateLimitPolicy{RequestsPerMinute: 2, TokensPerMinute: 1000}
+
+	status := s.RateLimitStatus("", "key", limits)
+	if status.Requests.Remaining != 2 || status.Tokens.Remaining != 1000 {
+		t.Fatalf("fresh windows: %+v %+v, want full", status.Requests, status.Tokens)
+	}
+
+	enfCtx := &EnforcementContext{APIKeyID: "key", Policy: &domain.RolePolicy{RateLimitPolicy: limits}}
+	for i := 0; i < 2; i++ {
+		if err := s.validateRateLimits(context.Background(), enfCtx); err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+	}
+
+	// Reporting the windows doesn't use them up
+	for i := 0; i < 2; i++ {
+		if status := s.RateLimitStatus("", "key", limits); status.Requests.Remaining != 0 {
+			t.Fatalf("after 2 requests: %+v, want 0 remaining", status.Requests)
+		}
+	}
+
+	err := s.validateRateLimits(context.Background(), enfCtx)
+	violation, ok := err.(*PolicyViolation)
+	if !ok || violation.RateLimit == nil || violation.RateLimit.Requests == nil {
+		t.Fatalf("third request: %v, want a violation with the refused window", err)
+	}
+	if w := violation.RateLimit.Requests; w.Limit != 2 || w.Remaining != 0 || time.Until(w.Reset) <= 0 {
+		t.Fatalf("refused window: %+v", w)
+	}
+	if violation.RateLimit.Exceeded != violation.RateLimit.Requests {
+		t.Fatalf("expected the requests window to be marked as the one exceeded, got %+v", violation.RateLimit.Exceeded)
+	}
+
+	if s.RateLimitStatus("", "key", domain.RateLimitPolicy{}) != nil {
+		t.Fatal("no limits: want nil status")
+	}
+}
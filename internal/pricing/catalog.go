@@ -0,0 +1,41 @@
+package pricing
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+
+	"modelgate/internal/domain"
+)
+
+//go:embed catalog.json
+var catalogJSON []byte
+
+type catalogEntry struct {
+	Provider             domain.Provider `json:"provider"`
+	Model                string          `json:"model"`
+	InputCostPer1M       float64         `json:"input_cost_per_1m"`
+	OutputCostPer1M      float64         `json:"output_cost_per_1m"`
+	CachedInputCostPer1M float64         `json:"cached_input_cost_per_1m,omitempty"`
+}
+
+// CatalogVersion is the date of the bundled pricing catalog
+var CatalogVersion string
+
+// catalog holds the bundled prices keyed by priceKey
+var catalog map[string]catalogEntry
+
+func init() {
+	var file struct {
+		Version string         `json:"version"`
+		Prices  []catalogEntry `json:"prices"`
+	}
+	if err := json.Unmarshal(catalogJSON, &file); err != nil {
+		panic(fmt.Sprintf("pricing: invalid bundled catalog: %v", err))
+	}
+	CatalogVersion = file.Version
+	catalog = make(map[string]catalogEntry, len(file.Prices))
+	for _, e := range file.Prices {
+		catalog[priceKey(e.Provider, e.Model)] = e
+	}
+}
@@ -0,0 +1,339 @@
+// Package pricing resolves what a model costs from a bundled catalog, prices
+// reported by providers and admin overrides, and keeps every price change as
+// history so recorded usage keeps the price it was billed at.
+package pricing
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+// Store is the persistence used by the service (implemented by postgres.TenantStore)
+type Store interface {
+	ListModelPrices(ctx context.Context, filter domain.ModelPriceFilter) ([]*domain.ModelPrice, error)
+	CreateModelPrice(ctx context.Context, price *domain.ModelPrice) error
+}
+
+// ModelSource lists models with the prices their providers currently report
+type ModelSource func(ctx context.Context) ([]domain.ModelInfo, error)
+
+// cacheTTL bounds how long a price change made on another instance takes to apply here
+const cacheTTL = time.Minute
+
+// Service looks up model prices from a cached copy of the pricing history
+type Service struct {
+	store  Store
+	source ModelSource
+
+	mu       sync.RWMutex
+	history  map[string][]*domain.ModelPrice // By priceKey, newest effective date first
+	loadedAt time.Time
+}
+
+// NewService creates a new pricing service. source may be nil, in which case
+// Refresh only records the bundled catalog.
+func NewService(store Store, source ModelSource) *Service {
+	return &Service{store: store, source: source}
+}
+
+// Override sets or ends an admin price for a model
+type Override struct {
+	Provider        domain.Provider
+	ModelID         string
+	InputCostPer1M  float64
+	OutputCostPer1M float64
+	Reset           bool      // End the current override instead of setting a price
+	EffectiveFrom   time.Time // Zero means now
+	Note            string
+	ActorEmail      string
+}
+
+// Price returns the price that applies to a model at the given time: an active
+// override, then the latest provider-reported price, then the catalog. It
+// returns nil for models with no known price. A nil service uses the bundled
+// catalog only.
+func (s *Service) Price(ctx context.Context, provider domain.Provider, model string, at time.Time) *domain.ModelPrice {
+	model = normalizeModel(provider, model)
+	var history []*domain.ModelPrice
+	if s != nil {
+		history = s.cached(ctx)[priceKey(provider, model)]
+	}
+	return resolve(provider, model, history, at)
+}
+
+// Current returns the price in effect now for every known model, optionally for one provider
+func (s *Service) Current(ctx context.Context, provider domain.Provider) ([]*domain.ModelPrice, error) {
+	stored, err := s.store.ListModelPrices(ctx, domain.ModelPriceFilter{Provider: provider})
+	if err != nil {
+		return nil, fmt.Errorf("list model prices: %w", err)
+	}
+	history := groupByModel(stored)
+
+	type model struct {
+		provider domain.Provider
+		id       string
+	}
+	models := make(map[string]model)
+	for _, p := range stored {
+		models[priceKey(p.Provider, p.ModelID)] = model{p.Provider, p.ModelID}
+	}
+	for key, e := range catalog {
+		if provider == "" || e.Provider == provider {
+			models[key] = model{e.Provider, e.Model}
+		}
+	}
+
+	now := time.Now()
+	prices := make([]*domain.ModelPrice, 0, len(models))
+	for key, m := range models {
+		if p := resolve(m.provider, m.id, history[key], now); p != nil {
+			prices = append(prices, p)
+		}
+	}
+	sort.Slice(prices, func(i, j int) bool {
+		if prices[i].Provider != prices[j].Provider {
+			return prices[i].Provider < prices[j].Provider
+		}
+		return prices[i].ModelID < prices[j].ModelID
+	})
+	return prices, nil
+}
+
+// History returns every recorded price of a model, newest effective date first
+func (s *Service) History(ctx context.Context, provider domain.Provider, model string) ([]*domain.ModelPrice, error) {
+	return s.store.ListModelPrices(ctx, domain.ModelPriceFilter{Provider: provider, ModelID: normalizeModel(provider, model)})
+}
+
+// SetOverride records an admin price (or the end of one). Overrides can only
+// take effect now or later, so costs already recorded are never repriced.
+func (s *Service) SetOverride(ctx context.Context, o Override) (*domain.ModelPrice, error) {
+	if o.Provider == "" || o.ModelID == "" {
+		return nil, fmt.Errorf("provider and model are required")
+	}
+	if o.InputCostPer1M < 0 || o.OutputCostPer1M < 0 {
+		return nil, fmt.Errorf("prices must not be negative")
+	}
+	now := time.Now()
+	if o.EffectiveFrom.IsZero() {
+		o.EffectiveFrom = now
+	} else if o.EffectiveFrom.Before(now.Add(-time.Minute)) {
+		return nil, fmt.Errorf("effective date must not be in the past")
+	}
+
+	price := &domain.ModelPrice{
+		Provider:        o.Provider,
+		ModelID:         normalizeModel(o.Provider, o.ModelID),
+		InputCostPer1M:  o.InputCostPer1M,
+		OutputCostPer1M: o.OutputCostPer1M,
+		Source:          domain.PriceSourceOverride,
+		Reset:           o.Reset,
+		EffectiveFrom:   o.EffectiveFrom,
+		Note:            o.Note,
+		CreatedBy:       o.ActorEmail,
+	}
+	if o.Reset {
+		price.InputCostPer1M, price.OutputCostPer1M = 0, 0
+	}
+	if err := s.store.CreateModelPrice(ctx, price); err != nil {
+		return nil, fmt.Errorf("save model price: %w", err)
+	}
+	s.invalidate()
+
+	slog.Info("Model price override set", "provider", price.Provider, "model", price.ModelID,
+		"input_cost_per_1m", price.InputCostPer1M, "output_cost_per_1m", price.OutputCostPer1M,
+		"reset", price.Reset, "effective_from", price.EffectiveFrom, "actor", o.ActorEmail)
+	return price, nil
+}
+
+// Refresh records bundled catalog prices and provider-reported prices that
+// differ from the latest recorded ones. It returns the number of new entries.
+func (s *Service) Refresh(ctx context.Context) (int, error) {
+	stored, err := s.store.ListModelPrices(ctx, domain.ModelPriceFilter{})
+	if err != nil {
+		return 0, fmt.Errorf("list model prices: %w", err)
+	}
+	latest := make(map[string]*domain.ModelPrice)
+	for _, p := range stored {
+		key := priceKey(p.Provider, p.ModelID) + "|" + string(p.Source)
+		if _, ok := latest[key]; !ok {
+			latest[key] = p
+		}
+	}
+
+	recorded := 0
+	record := func(provider domain.Provider, model string, input, output, cachedInput float64, source domain.PriceSource, note string) error {
+		if last := latest[priceKey(provider, model)+"|"+string(source)]; last != nil &&
+			last.InputCostPer1M == input && last.OutputCostPer1M == output && last.CachedInputCostPer1M == cachedInput {
+			return nil
+		}
+		price := &domain.ModelPrice{
+			Provider:             provider,
+			ModelID:              model,
+			InputCostPer1M:       input,
+			OutputCostPer1M:      output,
+			CachedInputCostPer1M: cachedInput,
+			Source:               source,
+			EffectiveFrom:        time.Now(),
+			Note:                 note,
+		}
+		if err := s.store.CreateModelPrice(ctx, price); err != nil {
+			return fmt.Errorf("save price for %s/%s: %w", provider, model, err)
+		}
+		recorded++
+		return nil
+	}
+	defer func() {
+		if recorded > 0 {
+			s.invalidate()
+		}
+	}()
+
+	for _, e := range catalog {
+		if err := record(e.Provider, e.Model, e.InputCostPer1M, e.OutputCostPer1M, e.CachedInputCostPer1M, domain.PriceSourceCatalog, "catalog "+CatalogVersion); err != nil {
+			return recorded, err
+		}
+	}
+
+	if s.source == nil {
+		return recorded, nil
+	}
+	models, err := s.source(ctx)
+	if err != nil {
+		return recorded, fmt.Errorf("list provider models: %w", err)
+	}
+	for _, m := range models {
+		// Zero means the provider doesn't report a price
+		if m.InputCostPer1M <= 0 && m.OutputCostPer1M <= 0 {
+			continue
+		}
+		if err := record(m.Provider, normalizeModel(m.Provider, m.ID), m.InputCostPer1M, m.OutputCostPer1M, m.CachedInputCostPer1M, domain.PriceSourceProvider, ""); err != nil {
+			return recorded, err
+		}
+	}
+	return recorded, nil
+}
+
+// StartRefresher refreshes prices now and then every interval until ctx is cancelled
+func (s *Service) StartRefresher(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	refresh := func() {
+		n, err := s.Refresh(ctx)
+		if err != nil {
+			slog.Warn("Failed to refresh model prices", "error", err)
+		}
+		if n > 0 {
+			slog.Info("Model prices changed", "entries", n)
+		}
+	}
+
+	go func() {
+		refresh()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				refresh()
+			}
+		}
+	}()
+}
+
+// resolve picks the price in effect at the given time from a model's history
+// (newest effective date first), falling back to the bundled catalog
+func resolve(provider domain.Provider, model string, history []*domain.ModelPrice, at time.Time) *domain.ModelPrice {
+	latest := make(map[domain.PriceSource]*domain.ModelPrice)
+	for _, p := range history {
+		if p.EffectiveFrom.After(at) {
+			continue
+		}
+		if _, ok := latest[p.Source]; !ok {
+			latest[p.Source] = p
+		}
+	}
+
+	if p := latest[domain.PriceSourceOverride]; p != nil && !p.Reset {
+		return p
+	}
+	if p := latest[domain.PriceSourceProvider]; p != nil {
+		return p
+	}
+	if p := latest[domain.PriceSourceCatalog]; p != nil {
+		return p
+	}
+	if e, ok := catalog[priceKey(provider, model)]; ok {
+		return &domain.ModelPrice{
+			Provider:             provider,
+			ModelID:              model,
+			InputCostPer1M:       e.InputCostPer1M,
+			OutputCostPer1M:      e.OutputCostPer1M,
+			CachedInputCostPer1M: e.CachedInputCostPer1M,
+			Source:               domain.PriceSourceCatalog,
+			Note:                 "catalog " + CatalogVersion,
+		}
+	}
+	return nil
+}
+
+// cached returns the pricing history, reloading it when the cache is stale.
+// On a load failure the previous copy (or none, meaning catalog prices) is used.
+func (s *Service) cached(ctx context.Context) map[string][]*domain.ModelPrice {
+	s.mu.RLock()
+	history, fresh := s.history, time.Since(s.loadedAt) < cacheTTL
+	s.mu.RUnlock()
+	if fresh {
+		return history
+	}
+
+	stored, err := s.store.ListModelPrices(ctx, domain.ModelPriceFilter{})
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loadedAt = time.Now() // Don't retry on every request after a failure
+	if err != nil {
+		slog.Warn("Failed to load model prices, using last known prices", "error", err)
+		return history
+	}
+	s.history = groupByModel(stored)
+	return s.history
+}
+
+func (s *Service) invalidate() {
+	s.mu.Lock()
+	s.loadedAt = time.Time{}
+	s.mu.Unlock()
+}
+
+func groupByModel(prices []*domain.ModelPrice) map[string][]*domain.ModelPrice {
+	grouped := make(map[string][]*domain.ModelPrice)
+	for _, p := range prices {
+		key := priceKey(p.Provider, p.ModelID)
+		grouped[key] = append(grouped[key], p)
+	}
+	return grouped
+}
+
+func priceKey(provider domain.Provider, model string) string {
+	return string(provider) + "/" + model
+}
+
+// normalizeModel strips the provider prefix from a model ID ("openai/gpt-4o" -> "gpt-4o").
+// Fireworks serverless models may also be named by their full account path.
+func normalizeModel(provider domain.Provider, model string) string {
+	model = strings.TrimPrefix(model, string(provider)+"/")
+	if provider == domain.ProviderFireworks {
+		model = strings.TrimPrefix(model, "accounts/fireworks/models/")
+	}
+	return model
+}
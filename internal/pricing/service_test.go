@@ -0,0 +1,88 @@
+package pricing
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+// memStore is an in-memory Store for tests
+type memStore struct {
+	prices []*domain.ModelPrice
+}
+
+func (m *memStore) ListModelPrices(_ context.Context, filter domain.ModelPriceFilter) ([]*domain.ModelPrice, error) {
+	var out []*domain.ModelPrice
+	for _, p := range m.prices {
+		if (filter.Provider == "" || p.Provider == filter.Provider) &&
+			(filter.ModelID == "" || p.ModelID == filter.ModelID) &&
+			(filter.Source == "" || p.Source == filter.Source) {
+			out = append(out, p)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].EffectiveFrom.After(out[j].EffectiveFrom) })
+	return out, nil
+}
+
+func (m *memStore) CreateModelPrice(_ context.Context, price *domain.ModelPrice) error {
+	price.CreatedAt = time.Now()
+	m.prices = append(m.prices, price)
+	return nil
+}
+
+func TestPriceResolution(t *testing.T) {
+	ctx := context.Background()
+	store := &memStore{}
+	source := func(context.Context) ([]domain.ModelInfo, error) {
+		return []domain.ModelInfo{{ID: "openai/gpt-4o", Provider: domain.ProviderOpenAI, InputCostPer1M: 2, OutputCostPer1M: 8}}, nil
+	}
+	svc := NewService(store, source)
+	before := time.Now().Add(-time.Hour)
+
+	if p := svc.Price(ctx, domain.ProviderOpenAI, "gpt-4o", time.Now()); p == nil || p.Source != domain.PriceSourceCatalog || p.InputCostPer1M != 2.5 {
+		t.Fatalf("expected bundled catalog price, got %+v", p)
+	}
+	if p := svc.Price(ctx, domain.ProviderFireworks, "fireworks/accounts/fireworks/models/deepseek-r1", time.Now()); p == nil || p.OutputCostPer1M != 8 {
+		t.Fatalf("expected a Fireworks model path to resolve to its catalog price, got %+v", p)
+	}
+
+	if _, err := svc.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if n, _ := svc.Refresh(ctx); n != 0 {
+		t.Fatalf("expected unchanged prices not to be recorded again, got %d entries", n)
+	}
+	if p := svc.Price(ctx, domain.ProviderOpenAI, "openai/gpt-4o", time.Now()); p.Source != domain.PriceSourceProvider || p.InputCostPer1M != 2 {
+		t.Fatalf("expected provider price to beat the catalog, got %+v", p)
+	}
+
+	future := time.Now().Add(time.Hour)
+	if _, err := svc.SetOverride(ctx, Override{Provider: domain.ProviderOpenAI, ModelID: "gpt-4o", InputCostPer1M: 1, OutputCostPer1M: 4, EffectiveFrom: future}); err != nil {
+		t.Fatalf("SetOverride failed: %v", err)
+	}
+	if p := svc.Price(ctx, domain.ProviderOpenAI, "gpt-4o", time.Now()); p.Source != domain.PriceSourceProvider {
+		t.Fatalf("expected future override not to apply yet, got %+v", p)
+	}
+	if p := svc.Price(ctx, domain.ProviderOpenAI, "gpt-4o", future.Add(time.Minute)); p.Source != domain.PriceSourceOverride || p.InputCostPer1M != 1 {
+		t.Fatalf("expected override once effective, got %+v", p)
+	}
+
+	if _, err := svc.SetOverride(ctx, Override{Provider: domain.ProviderOpenAI, ModelID: "gpt-4o", Reset: true, EffectiveFrom: future.Add(time.Hour)}); err != nil {
+		t.Fatalf("SetOverride reset failed: %v", err)
+	}
+	if p := svc.Price(ctx, domain.ProviderOpenAI, "gpt-4o", future.Add(2*time.Hour)); p.Source != domain.PriceSourceProvider {
+		t.Fatalf("expected reset to return to the provider price, got %+v", p)
+	}
+
+	// Usage recorded before any refresh keeps the catalog price
+	if p := svc.Price(ctx, domain.ProviderOpenAI, "gpt-4o", before); p.Source != domain.PriceSourceCatalog {
+		t.Fatalf("expected historical lookup to use the old price, got %+v", p)
+	}
+
+	if _, err := svc.SetOverride(ctx, Override{Provider: domain.ProviderOpenAI, ModelID: "gpt-4o", InputCostPer1M: 1, EffectiveFrom: before}); err == nil {
+		t.Fatal("expected override in the past to be rejected")
+	}
+}
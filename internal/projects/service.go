@@ -0,0 +1,255 @@
+// Package projects manages projects, the level between the tenant and its API
+// keys, and enforces each project's budget on incoming requests.
+package projects
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+// spendCacheTTL is how long a project's spend is reused before it is queried
+// again. Budgets can be overshot by the requests made within this window.
+const spendCacheTTL = 30 * time.Second
+
+var (
+	// ErrProjectNotFound is returned for an unknown project ID
+	ErrProjectNotFound = errors.New("project not found")
+	// ErrInvalidProject is returned when a project fails validation
+	ErrInvalidProject = errors.New("invalid project")
+)
+
+// Store is the persistence used by the service (implemented by postgres.TenantStore)
+type Store interface {
+	CreateProject(ctx context.Context, p *domain.Project) error
+	GetProject(ctx context.Context, id string) (*domain.Project, error)
+	ListProjects(ctx context.Context) ([]*domain.Project, error)
+	UpdateProject(ctx context.Context, p *domain.Project) error
+	DeleteProject(ctx context.Context, id string) (bool, error)
+	UpdateAPIKeyProject(ctx context.Context, keyID, projectID string) error
+	GetProjectSpend(ctx context.Context, projectID string, dayStart, weekStart, monthStart time.Time) (*domain.ProjectSpend, error)
+	GetUsageStatsByProject(ctx context.Context, start, end time.Time) ([]*domain.ProjectUsageStats, error)
+}
+
+// BudgetCheck is the outcome of checking a request against its project's budget
+type BudgetCheck struct {
+	Allowed bool
+	Warning string // Set when a limit or alert threshold was reached but the request is allowed
+	Reason  string // Set when the request is blocked
+}
+
+type cachedSpend struct {
+	spend   *domain.ProjectSpend
+	project *domain.Project
+	at      time.Time
+}
+
+// Service manages projects and their budgets
+type Service struct {
+	store Store
+	now   func() time.Time
+
+	mu    sync.Mutex
+	spend map[string]cachedSpend // By project ID
+}
+
+// NewService creates a new project service
+func NewService(store Store) *Service {
+	return &Service{store: store, now: time.Now, spend: make(map[string]cachedSpend)}
+}
+
+// budgetWindows returns the start of the UTC day, week (from Monday) and month containing t
+func budgetWindows(t time.Time) (day, week, month time.Time) {
+	t = t.UTC()
+	day = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	week = day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	month = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return day, week, month
+}
+
+func validate(p *domain.Project) error {
+	p.Name = strings.TrimSpace(p.Name)
+	if p.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidProject)
+	}
+	bp := p.BudgetPolicy
+	if bp.DailyLimitUSD < 0 || bp.WeeklyLimitUSD < 0 || bp.MonthlyLimitUSD < 0 || bp.SoftLimitBuffer < 0 {
+		return fmt.Errorf("%w: budget limits cannot be negative", ErrInvalidProject)
+	}
+	return nil
+}
+
+// Create creates a project
+func (s *Service) Create(ctx context.Context, p *domain.Project) error {
+	if err := validate(p); err != nil {
+		return err
+	}
+	if err := s.store.CreateProject(ctx, p); err != nil {
+		return fmt.Errorf("create project: %w", err)
+	}
+	return nil
+}
+
+// Get returns a project
+func (s *Service) Get(ctx context.Context, id string) (*domain.Project, error) {
+	p, err := s.store.GetProject(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("get project: %w", err)
+	}
+	if p == nil {
+		return nil, ErrProjectNotFound
+	}
+	return p, nil
+}
+
+// List returns all projects
+func (s *Service) List(ctx context.Context) ([]*domain.Project, error) {
+	projects, err := s.store.ListProjects(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list projects: %w", err)
+	}
+	return projects, nil
+}
+
+// Update saves a project's name, description and budget policy
+func (s *Service) Update(ctx context.Context, p *domain.Project) error {
+	if err := validate(p); err != nil {
+		return err
+	}
+	if err := s.store.UpdateProject(ctx, p); err != nil {
+		return fmt.Errorf("update project: %w", err)
+	}
+	s.forget(p.ID)
+	return nil
+}
+
+// Delete deletes a project. Its API keys no longer belong to any project.
+func (s *Service) Delete(ctx context.Context, id string) error {
+	ok, err := s.store.DeleteProject(ctx, id)
+	if err != nil {
+		return fmt.Errorf("delete project: %w", err)
+	}
+	if !ok {
+		return ErrProjectNotFound
+	}
+	s.forget(id)
+	return nil
+}
+
+// AssignAPIKey moves an API key into a project; an empty projectID removes it
+// from its project
+func (s *Service) AssignAPIKey(ctx context.Context, keyID, projectID string) error {
+	if projectID != "" {
+		if _, err := s.Get(ctx, projectID); err != nil {
+			return err
+		}
+	}
+	if err := s.store.UpdateAPIKeyProject(ctx, keyID, projectID); err != nil {
+		return fmt.Errorf("assign API key to project: %w", err)
+	}
+	return nil
+}
+
+// Spend returns a project's cost in the current day, week and month
+func (s *Service) Spend(ctx context.Context, projectID string) (*domain.ProjectSpend, error) {
+	day, week, month := budgetWindows(s.now())
+	spend, err := s.store.GetProjectSpend(ctx, projectID, day, week, month)
+	if err != nil {
+		return nil, fmt.Errorf("get project spend: %w", err)
+	}
+	return spend, nil
+}
+
+// Usage returns usage grouped by project
+func (s *Service) Usage(ctx context.Context, start, end time.Time) ([]*domain.ProjectUsageStats, error) {
+	stats, err := s.store.GetUsageStatsByProject(ctx, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("get project usage: %w", err)
+	}
+	return stats, nil
+}
+
+// CheckBudget checks whether a request billed to the project may proceed.
+// Spend is cached briefly, so a burst of requests can overshoot a limit by
+// what it costs within that window. Throttle behaves like warn, since a
+// project has no rate limit of its own to reduce.
+func (s *Service) CheckBudget(ctx context.Context, projectID string) (*BudgetCheck, error) {
+	allowed := &BudgetCheck{Allowed: true}
+	if projectID == "" {
+		return allowed, nil
+	}
+
+	project, spend, err := s.cachedSpend(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if project == nil || !project.BudgetPolicy.Enabled {
+		return allowed, nil
+	}
+
+	bp := project.BudgetPolicy
+	windows := []struct {
+		name  string
+		limit float64
+		spent float64
+	}{
+		{"daily", bp.DailyLimitUSD, spend.DailyUSD},
+		{"weekly", bp.WeeklyLimitUSD, spend.WeeklyUSD},
+		{"monthly", bp.MonthlyLimitUSD, spend.MonthlyUSD},
+	}
+	for _, w := range windows {
+		if w.limit <= 0 {
+			continue
+		}
+		hardLimit := w.limit
+		if bp.SoftLimitEnabled {
+			hardLimit *= 1 + bp.SoftLimitBuffer
+		}
+		switch {
+		case w.spent >= hardLimit && bp.OnExceeded == domain.BudgetActionBlock:
+			return &BudgetCheck{Reason: fmt.Sprintf("project %q exceeded its %s budget of $%.2f", project.Name, w.name, w.limit)}, nil
+		case w.spent >= w.limit:
+			allowed.Warning = fmt.Sprintf("%s budget of $%.2f exceeded", w.name, w.limit)
+		case allowed.Warning == "" && bp.AlertThreshold > 0 && w.spent >= w.limit*bp.AlertThreshold:
+			allowed.Warning = fmt.Sprintf("%.0f%% of %s budget used", 100*w.spent/w.limit, w.name)
+		}
+	}
+	return allowed, nil
+}
+
+func (s *Service) cachedSpend(ctx context.Context, projectID string) (*domain.Project, *domain.ProjectSpend, error) {
+	now := s.now()
+	s.mu.Lock()
+	c, ok := s.spend[projectID]
+	s.mu.Unlock()
+	if ok && now.Sub(c.at) < spendCacheTTL {
+		return c.project, c.spend, nil
+	}
+
+	project, err := s.store.GetProject(ctx, projectID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get project: %w", err)
+	}
+	spend := &domain.ProjectSpend{}
+	if project != nil && project.BudgetPolicy.Enabled {
+		if spend, err = s.Spend(ctx, projectID); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	s.mu.Lock()
+	s.spend[projectID] = cachedSpend{spend: spend, project: project, at: now}
+	s.mu.Unlock()
+	return project, spend, nil
+}
+
+func (s *Service) forget(projectID string) {
+	s.mu.Lock()
+	delete(s.spend, projectID)
+	s.mu.Unlock()
+}
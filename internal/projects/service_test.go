@@ -0,0 +1,203 @@
+package projects
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+type charge struct {
+	project string
+	at      time.Time
+	cost    float64
+}
+
+type memStore struct {
+	projects map[string]*domain.Project
+	keys     map[string]string // API key ID -> project ID
+	charges  []charge
+	queries  int
+}
+
+func newMemStore() *memStore {
+	return &memStore{projects: map[string]*domain.Project{}, keys: map[string]string{}}
+}
+
+func (m *memStore) CreateProject(ctx context.Context, p *domain.Project) error {
+	p.ID = fmt.Sprintf("p%d", len(m.projects)+1)
+	c := *p
+	m.projects[p.ID] = &c
+	return nil
+}
+
+func (m *memStore) GetProject(ctx context.Context, id string) (*domain.Project, error) {
+	p, ok := m.projects[id]
+	if !ok {
+		return nil, nil
+	}
+	c := *p
+	return &c, nil
+}
+
+func (m *memStore) ListProjects(ctx context.Context) ([]*domain.Project, error) {
+	var out []*domain.Project
+	for _, p := range m.projects {
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+func (m *memStore) UpdateProject(ctx context.Context, p *domain.Project) error {
+	c := *p
+	m.projects[p.ID] = &c
+	return nil
+}
+
+func (m *memStore) DeleteProject(ctx context.Context, id string) (bool, error) {
+	_, ok := m.projects[id]
+	delete(m.projects, id)
+	return ok, nil
+}
+
+func (m *memStore) UpdateAPIKeyProject(ctx context.Context, keyID, projectID string) error {
+	m.keys[keyID] = projectID
+	return nil
+}
+
+func (m *memStore) GetProjectSpend(ctx context.Context, projectID string, dayStart, weekStart, monthStart time.Time) (*domain.ProjectSpend, error) {
+	m.queries++
+	var s domain.ProjectSpend
+	for _, c := range m.charges {
+		if c.project != projectID {
+			continue
+		}
+		if !c.at.Before(dayStart) {
+			s.DailyUSD += c.cost
+		}
+		if !c.at.Before(weekStart) {
+			s.WeeklyUSD += c.cost
+		}
+		if !c.at.Before(monthStart) {
+			s.MonthlyUSD += c.cost
+		}
+	}
+	return &s, nil
+}
+
+func (m *memStore) GetUsageStatsByProject(ctx context.Context, start, end time.Time) ([]*domain.ProjectUsageStats, error) {
+	return nil, nil
+}
+
+func TestBudgetWindows(t *testing.T) {
+	// Sunday 2 March 2025: the week started on Monday 24 February
+	day, week, month := budgetWindows(time.Date(2025, 3, 2, 15, 0, 0, 0, time.UTC))
+	if !day.Equal(time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC)) ||
+		!week.Equal(time.Date(2025, 2, 24, 0, 0, 0, 0, time.UTC)) ||
+		!month.Equal(time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected windows %v %v %v", day, week, month)
+	}
+}
+
+func TestValidation(t *testing.T) {
+	svc := NewService(newMemStore())
+	ctx := context.Background()
+	if err := svc.Create(ctx, &domain.Project{Name: "  "}); !errors.Is(err, ErrInvalidProject) {
+		t.Fatalf("expected ErrInvalidProject for a blank name, got %v", err)
+	}
+	p := &domain.Project{Name: "search", BudgetPolicy: domain.BudgetPolicy{DailyLimitUSD: -1}}
+	if err := svc.Create(ctx, p); !errors.Is(err, ErrInvalidProject) {
+		t.Fatalf("expected ErrInvalidProject for a negative limit, got %v", err)
+	}
+	if _, err := svc.Get(ctx, "missing"); !errors.Is(err, ErrProjectNotFound) {
+		t.Fatalf("expected ErrProjectNotFound, got %v", err)
+	}
+	if err := svc.AssignAPIKey(ctx, "key1", "missing"); !errors.Is(err, ErrProjectNotFound) {
+		t.Fatalf("expected ErrProjectNotFound when assigning a key, got %v", err)
+	}
+}
+
+func TestCheckBudget(t *testing.T) {
+	store := newMemStore()
+	svc := NewService(store)
+	now := time.Date(2025, 3, 12, 12, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	p := &domain.Project{Name: "search", BudgetPolicy: domain.BudgetPolicy{
+		Enabled:         true,
+		DailyLimitUSD:   10,
+		MonthlyLimitUSD: 100,
+		AlertThreshold:  0.8,
+		OnExceeded:      domain.BudgetActionBlock,
+	}}
+	if err := svc.Create(ctx, p); err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(wantAllowed bool, wantWarning bool) {
+		t.Helper()
+		c, err := svc.CheckBudget(ctx, p.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Allowed != wantAllowed || (c.Warning != "") != wantWarning {
+			t.Fatalf("got %+v, want allowed=%v warning=%v", c, wantAllowed, wantWarning)
+		}
+	}
+
+	store.charges = append(store.charges, charge{p.ID, now.Add(-time.Hour), 5})
+	check(true, false)
+
+	// Spend is cached until the TTL passes
+	store.charges = append(store.charges, charge{p.ID, now.Add(-time.Hour), 4})
+	check(true, false)
+	now = now.Add(spendCacheTTL)
+	check(true, true) // $9 of $10: over the alert threshold
+
+	store.charges = append(store.charges, charge{p.ID, now, 1})
+	now = now.Add(spendCacheTTL)
+	check(false, false)
+
+	// Yesterday's spend only counts toward the month
+	now = now.Add(24 * time.Hour)
+	check(true, false)
+
+	// A soft limit allows going over by the buffer, with a warning
+	p.BudgetPolicy.SoftLimitEnabled = true
+	p.BudgetPolicy.SoftLimitBuffer = 0.5
+	if err := svc.Update(ctx, p); err != nil {
+		t.Fatal(err)
+	}
+	store.charges = append(store.charges, charge{p.ID, now, 12})
+	check(true, true)
+	store.charges = append(store.charges, charge{p.ID, now, 3})
+	now = now.Add(spendCacheTTL)
+	check(false, false)
+
+	// Warn never blocks
+	p.BudgetPolicy.OnExceeded = domain.BudgetActionWarn
+	if err := svc.Update(ctx, p); err != nil {
+		t.Fatal(err)
+	}
+	check(true, true)
+
+	// Keys outside any project and projects without a budget are not checked
+	queries := store.queries
+	if c, err := svc.CheckBudget(ctx, ""); err != nil || !c.Allowed {
+		t.Fatalf("expected requests without a project to be allowed, got %+v %v", c, err)
+	}
+	free := &domain.Project{Name: "free"}
+	if err := svc.Create(ctx, free); err != nil {
+		t.Fatal(err)
+	}
+	if c, err := svc.CheckBudget(ctx, free.ID); err != nil || !c.Allowed {
+		t.Fatalf("expected a project without a budget to be allowed, got %+v %v", c, err)
+	}
+	if store.queries != queries {
+		t.Fatalf("spend was queried for a project without a budget")
+	}
+}
@@ -4,6 +4,7 @@ package provider
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -108,12 +109,12 @@ func (c *AnthropicClient) ChatStream(ctx context.Context, req *domain.ChatReques
 			return
 		}
 		defer resp.Body.Close()
+		observeQuota(ctx, resp.Header)
 
 		if resp.StatusCode != http.StatusOK {
 			bodyBytes, _ := io.ReadAll(resp.Body)
-			eventChan <- domain.PolicyViolationEvent{
-				Message: fmt.Sprintf("API error: %s - %s", resp.Status, string(bodyBytes)),
-			}
+			eventChan <- domain.ErrorEvent{Err: NewHTTPError(c.Provider(), resp.StatusCode, bodyBytes)}
+			eventChan <- domain.FinishEvent{Reason: domain.FinishReasonError}
 			return
 		}
 
@@ -148,17 +149,26 @@ func (c *AnthropicClient) ChatComplete(ctx context.Context, req *domain.ChatRequ
 		return nil, err
 	}
 	defer resp.Body.Close()
+	observeQuota(ctx, resp.Header)
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error: %s - %s", resp.Status, string(bodyBytes))
+		return nil, NewHTTPError(c.Provider(), resp.StatusCode, bodyBytes)
 	}
 
+	return decodeAnthropicMessageResponse(resp.Body, req.Model)
+}
+
+// decodeAnthropicMessageResponse decodes a Messages API response body into a ChatResponse
+func decodeAnthropicMessageResponse(r io.Reader, model string) (*domain.ChatResponse, error) {
 	var result struct {
 		ID      string `json:"id"`
 		Content []struct {
-			Type string `json:"type"`
-			Text string `json:"text"`
+			Type  string         `json:"type"`
+			Text  string         `json:"text"`
+			ID    string         `json:"id"`
+			Name  string         `json:"name"`
+			Input map[string]any `json:"input"`
 		} `json:"content"`
 		StopReason string `json:"stop_reason"`
 		Usage      struct {
@@ -167,14 +177,25 @@ func (c *AnthropicClient) ChatComplete(ctx context.Context, req *domain.ChatRequ
 		} `json:"usage"`
 	}
 
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+	if err := json.NewDecoder(r).Decode(&result); err != nil {
 		return nil, err
 	}
 
 	var content strings.Builder
+	var toolCalls []domain.ToolCall
 	for _, c := range result.Content {
-		if c.Type == "text" {
+		switch c.Type {
+		case "text":
 			content.WriteString(c.Text)
+		case "tool_use":
+			toolCalls = append(toolCalls, domain.ToolCall{
+				ID:   c.ID,
+				Type: "function",
+				Function: domain.FunctionCall{
+					Name:      c.Name,
+					Arguments: c.Input,
+				},
+			})
 		}
 	}
 
@@ -191,8 +212,9 @@ func (c *AnthropicClient) ChatComplete(ctx context.Context, req *domain.ChatRequ
 	}
 
 	return &domain.ChatResponse{
-		Content: content.String(),
-		Model:   req.Model,
+		Content:   content.String(),
+		Model:     model,
+		ToolCalls: toolCalls,
 		Usage: &domain.UsageEvent{
 			PromptTokens:     result.Usage.InputTokens,
 			CompletionTokens: result.Usage.OutputTokens,
@@ -231,6 +253,7 @@ func (c *AnthropicClient) CountTokens(ctx context.Context, req *domain.ChatReque
 		return 0, err
 	}
 	defer resp.Body.Close()
+	observeQuota(ctx, resp.Header)
 
 	var result struct {
 		InputTokens int32 `json:"input_tokens"`
@@ -299,6 +322,9 @@ func (c *AnthropicClient) buildRequest(req *domain.ChatRequest) map[string]any {
 	if req.Temperature != nil {
 		anthropicReq["temperature"] = *req.Temperature
 	}
+	if req.TopP != nil {
+		anthropicReq["top_p"] = *req.TopP
+	}
 
 	if req.SystemPrompt != "" {
 		anthropicReq["system"] = req.SystemPrompt
@@ -327,6 +353,10 @@ func (c *AnthropicClient) buildRequest(req *domain.ChatRequest) map[string]any {
 							},
 						})
 					}
+				case "file":
+					if len(c.FileData) > 0 {
+						content = append(content, anthropicFileBlock(c))
+					}
 				case "tool_result":
 					if c.ToolResult != nil {
 						var resultContent []map[string]any
@@ -414,6 +444,7 @@ func (c *AnthropicClient) buildRequest(req *domain.ChatRequest) map[string]any {
 func (c *AnthropicClient) parseSSEStream(body io.Reader, eventChan chan<- domain.StreamEvent) {
 	buf := make([]byte, 4096)
 	var lineBuffer strings.Builder
+	var toolCalls toolCallStream
 
 	for {
 		n, err := body.Read(buf)
@@ -434,7 +465,7 @@ func (c *AnthropicClient) parseSSEStream(body io.Reader, eventChan chan<- domain
 				line = strings.TrimSpace(line)
 				if strings.HasPrefix(line, "data: ") {
 					data := strings.TrimPrefix(line, "data: ")
-					c.parseChunk(data, eventChan)
+					c.parseChunk(data, eventChan, &toolCalls)
 				}
 			}
 		}
@@ -449,14 +480,15 @@ func (c *AnthropicClient) parseSSEStream(body io.Reader, eventChan chan<- domain
 }
 
 // parseChunk parses a JSON chunk from the stream
-func (c *AnthropicClient) parseChunk(data string, eventChan chan<- domain.StreamEvent) {
+func (c *AnthropicClient) parseChunk(data string, eventChan chan<- domain.StreamEvent, toolCalls *toolCallStream) {
 	var event struct {
 		Type  string `json:"type"`
 		Index int    `json:"index"`
 		Delta struct {
-			Type       string `json:"type"`
-			Text       string `json:"text"`
-			StopReason string `json:"stop_reason"`
+			Type        string `json:"type"`
+			Text        string `json:"text"`
+			PartialJSON string `json:"partial_json"`
+			StopReason  string `json:"stop_reason"`
 		} `json:"delta"`
 		ContentBlock struct {
 			Type  string `json:"type"`
@@ -487,11 +519,14 @@ func (c *AnthropicClient) parseChunk(data string, eventChan chan<- domain.Stream
 			eventChan <- domain.TextChunk{Content: event.Delta.Text}
 		} else if event.Delta.Type == "thinking_delta" && event.Delta.Text != "" {
 			eventChan <- domain.ThinkingChunk{Content: event.Delta.Text}
+		} else if event.Delta.Type == "input_json_delta" {
+			toolCalls.add(eventChan, event.Index, "", "", event.Delta.PartialJSON)
 		}
 
 	case "content_block_start":
 		if event.ContentBlock.Type == "tool_use" {
-			// Tool use block started - we'll accumulate input
+			// Tool input follows as input_json_delta fragments of this block
+			toolCalls.add(eventChan, event.Index, event.ContentBlock.ID, event.ContentBlock.Name, "")
 		}
 
 	case "content_block_stop":
@@ -507,6 +542,7 @@ func (c *AnthropicClient) parseChunk(data string, eventChan chan<- domain.Stream
 			}
 		}
 		if event.Delta.StopReason != "" {
+			toolCalls.flush(eventChan)
 			var reason domain.FinishReason
 			switch event.Delta.StopReason {
 			case "end_turn":
@@ -537,3 +573,26 @@ func mustMarshal(v any) string {
 	b, _ := json.Marshal(v)
 	return string(b)
 }
+
+// anthropicFileBlock converts a file block with inline content to an image
+// or document block. Plain text documents are sent as text, everything else
+// base64 encoded.
+func anthropicFileBlock(c domain.ContentBlock) map[string]any {
+	blockType := "document"
+	source := map[string]any{
+		"type":       "base64",
+		"media_type": c.MediaType,
+		"data":       base64.StdEncoding.EncodeToString(c.FileData),
+	}
+	switch {
+	case strings.HasPrefix(c.MediaType, "image/"):
+		blockType = "image"
+	case strings.HasPrefix(c.MediaType, "text/plain"):
+		source = map[string]any{"type": "text", "media_type": "text/plain", "data": string(c.FileData)}
+	}
+	block := map[string]any{"type": blockType, "source": source}
+	if blockType == "document" && c.Filename != "" {
+		block["title"] = c.Filename
+	}
+	return block
+}
@@ -0,0 +1,201 @@
+// Package provider implements LLM provider clients.
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"modelgate/internal/domain"
+)
+
+// anthropicBatchDiscount is the Message Batches API price multiplier (50% off)
+const anthropicBatchDiscount = 0.5
+
+// anthropicBatch is the Message Batches API batch object
+type anthropicBatch struct {
+	ID               string `json:"id"`
+	ProcessingStatus string `json:"processing_status"` // in_progress, canceling, ended
+	ResultsURL       string `json:"results_url"`
+	RequestCounts    struct {
+		Processing int `json:"processing"`
+		Succeeded  int `json:"succeeded"`
+		Errored    int `json:"errored"`
+		Canceled   int `json:"canceled"`
+		Expired    int `json:"expired"`
+	} `json:"request_counts"`
+}
+
+// SubmitBatch creates a Message Batch (implements BatchCapable).
+// Anthropic requires custom IDs to match ^[a-zA-Z0-9_-]{1,64}$.
+func (c *AnthropicClient) SubmitBatch(ctx context.Context, items []domain.BatchItem) (string, error) {
+	requests := make([]map[string]any, len(items))
+	for i, item := range items {
+		requests[i] = map[string]any{
+			"custom_id": item.CustomID,
+			"params":    c.buildRequest(item.Request),
+		}
+	}
+
+	var batch anthropicBatch
+	if err := c.doJSON(ctx, "POST", c.baseURL+"/messages/batches", map[string]any{"requests": requests}, &batch); err != nil {
+		return "", fmt.Errorf("create message batch: %w", err)
+	}
+	return batch.ID, nil
+}
+
+// GetBatchStatus polls a Message Batch (implements BatchCapable)
+func (c *AnthropicClient) GetBatchStatus(ctx context.Context, providerBatchID string) (*domain.ProviderBatchStatus, error) {
+	batch, err := c.getBatch(ctx, providerBatchID)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := batch.RequestCounts
+	status := &domain.ProviderBatchStatus{
+		RequestCounts: domain.BatchCounts{
+			Total:     counts.Processing + counts.Succeeded + counts.Errored + counts.Canceled + counts.Expired,
+			Completed: counts.Succeeded,
+			Failed:    counts.Errored + counts.Canceled + counts.Expired,
+		},
+	}
+
+	switch batch.ProcessingStatus {
+	case "ended":
+		status.Status = domain.BatchStatusCompleted
+		if counts.Succeeded == 0 && counts.Canceled > 0 {
+			status.Status = domain.BatchStatusCancelled
+		}
+	case "canceling":
+		status.Status = domain.BatchStatusCancelling
+	default:
+		status.Status = domain.BatchStatusInProgress
+	}
+	return status, nil
+}
+
+// GetBatchResults downloads the JSONL results of an ended Message Batch (implements BatchCapable)
+func (c *AnthropicClient) GetBatchResults(ctx context.Context, providerBatchID string) ([]domain.BatchItemResult, error) {
+	batch, err := c.getBatch(ctx, providerBatchID)
+	if err != nil {
+		return nil, err
+	}
+	if batch.ResultsURL == "" {
+		return nil, fmt.Errorf("message batch %s has no results yet", providerBatchID)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", batch.ResultsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("x-api-key", c.apiKey)
+	httpReq.Header.Set("anthropic-version", anthropicAPIVersion)
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("download batch results: %s - %s", resp.Status, string(bodyBytes))
+	}
+
+	var results []domain.BatchItemResult
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var line struct {
+			CustomID string `json:"custom_id"`
+			Result   struct {
+				Type    string          `json:"type"` // succeeded, errored, canceled, expired
+				Message json.RawMessage `json:"message"`
+				Error   struct {
+					Error struct {
+						Message string `json:"message"`
+					} `json:"error"`
+				} `json:"error"`
+			} `json:"result"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			continue
+		}
+
+		result := domain.BatchItemResult{CustomID: line.CustomID}
+		switch line.Result.Type {
+		case "succeeded":
+			result.StatusCode = http.StatusOK
+			result.Response, err = decodeAnthropicMessageResponse(bytes.NewReader(line.Result.Message), "")
+			if err != nil {
+				result.Error = fmt.Sprintf("invalid message: %v", err)
+			}
+		case "errored":
+			result.Error = line.Result.Error.Error.Message
+		default:
+			result.Error = "request " + line.Result.Type
+		}
+		results = append(results, result)
+	}
+	return results, scanner.Err()
+}
+
+// CancelBatch cancels a Message Batch (implements BatchCapable)
+func (c *AnthropicClient) CancelBatch(ctx context.Context, providerBatchID string) error {
+	return c.doJSON(ctx, "POST", c.baseURL+"/messages/batches/"+providerBatchID+"/cancel", nil, nil)
+}
+
+// BatchDiscount returns the Message Batches price multiplier (implements BatchCapable)
+func (c *AnthropicClient) BatchDiscount() float64 {
+	return anthropicBatchDiscount
+}
+
+func (c *AnthropicClient) getBatch(ctx context.Context, providerBatchID string) (*anthropicBatch, error) {
+	var batch anthropicBatch
+	if err := c.doJSON(ctx, "GET", c.baseURL+"/messages/batches/"+providerBatchID, nil, &batch); err != nil {
+		return nil, fmt.Errorf("get message batch: %w", err)
+	}
+	return &batch, nil
+}
+
+// doJSON sends a JSON request to the Anthropic API and decodes the JSON response into out (if non-nil)
+func (c *AnthropicClient) doJSON(ctx context.Context, method, url string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	httpReq.Header.Set("x-api-key", c.apiKey)
+	httpReq.Header.Set("anthropic-version", anthropicAPIVersion)
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("API error: %s - %s", resp.Status, string(bodyBytes))
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
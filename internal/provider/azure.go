@@ -156,11 +156,20 @@ func (c *AzureOpenAIClient) ChatStream(ctx context.Context, req *domain.ChatRequ
 		if req.Temperature != nil {
 			body["temperature"] = *req.Temperature
 		}
+		if req.TopP != nil {
+			body["top_p"] = *req.TopP
+		}
 		if req.MaxTokens != nil {
 			body["max_tokens"] = *req.MaxTokens
 		}
 		if len(req.Tools) > 0 {
 			body["tools"] = c.convertTools(req.Tools)
+			if tc := openAIToolChoice(req.ToolChoice); tc != nil {
+				body["tool_choice"] = tc
+			}
+		}
+		if rf := openAIResponseFormat(req.ResponseFormat); rf != nil {
+			body["response_format"] = rf
 		}
 
 		jsonBody, _ := json.Marshal(body)
@@ -179,10 +188,11 @@ func (c *AzureOpenAIClient) ChatStream(ctx context.Context, req *domain.ChatRequ
 			return
 		}
 		defer resp.Body.Close()
+		observeQuota(ctx, resp.Header)
 
 		if resp.StatusCode != http.StatusOK {
 			bodyBytes, _ := io.ReadAll(resp.Body)
-			events <- domain.TextChunk{Content: fmt.Sprintf("Azure OpenAI error: %s", string(bodyBytes))}
+			events <- domain.ErrorEvent{Err: NewHTTPError(c.Provider(), resp.StatusCode, bodyBytes)}
 			events <- domain.FinishEvent{Reason: domain.FinishReasonError}
 			return
 		}
@@ -212,11 +222,20 @@ func (c *AzureOpenAIClient) ChatComplete(ctx context.Context, req *domain.ChatRe
 	if req.Temperature != nil {
 		body["temperature"] = *req.Temperature
 	}
+	if req.TopP != nil {
+		body["top_p"] = *req.TopP
+	}
 	if req.MaxTokens != nil {
 		body["max_tokens"] = *req.MaxTokens
 	}
 	if len(req.Tools) > 0 {
 		body["tools"] = c.convertTools(req.Tools)
+		if tc := openAIToolChoice(req.ToolChoice); tc != nil {
+			body["tool_choice"] = tc
+		}
+	}
+	if rf := openAIResponseFormat(req.ResponseFormat); rf != nil {
+		body["response_format"] = rf
 	}
 
 	jsonBody, _ := json.Marshal(body)
@@ -233,10 +252,11 @@ func (c *AzureOpenAIClient) ChatComplete(ctx context.Context, req *domain.ChatRe
 		return nil, err
 	}
 	defer resp.Body.Close()
+	observeQuota(ctx, resp.Header)
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("Azure OpenAI API error: %s", string(bodyBytes))
+		return nil, NewHTTPError(c.Provider(), resp.StatusCode, bodyBytes)
 	}
 
 	var result struct {
@@ -327,10 +347,11 @@ func (c *AzureOpenAIClient) Embed(ctx context.Context, model string, texts []str
 		return nil, 0, err
 	}
 	defer resp.Body.Close()
+	observeQuota(ctx, resp.Header)
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, 0, fmt.Errorf("Azure OpenAI API error: %s", string(bodyBytes))
+		return nil, 0, NewHTTPError(c.Provider(), resp.StatusCode, bodyBytes)
 	}
 
 	var result struct {
@@ -483,6 +504,8 @@ func (c *AzureOpenAIClient) buildMessages(req *domain.ChatRequest) []map[string]
 							"url": block.ImageURL,
 						},
 					})
+				case "file":
+					content = append(content, openAIFilePart(block))
 				}
 			}
 			m["content"] = content
@@ -532,6 +555,7 @@ func (c *AzureOpenAIClient) convertTools(tools []domain.Tool) []map[string]any {
 func (c *AzureOpenAIClient) processSSEStream(body io.Reader, events chan<- domain.StreamEvent) {
 	reader := NewSSEReader(body)
 	var inputTokens, outputTokens int32
+	var toolCalls toolCallStream
 
 	for {
 		event, err := reader.ReadEvent()
@@ -544,6 +568,7 @@ func (c *AzureOpenAIClient) processSSEStream(body io.Reader, events chan<- domai
 		}
 
 		if event.Data == "[DONE]" {
+			toolCalls.flush(events)
 			events <- domain.UsageEvent{
 				PromptTokens:     inputTokens,
 				CompletionTokens: outputTokens,
@@ -591,25 +616,11 @@ func (c *AzureOpenAIClient) processSSEStream(body io.Reader, events chan<- domai
 			}
 
 			for _, tc := range delta.ToolCalls {
-				if tc.ID != "" {
-					var args map[string]any
-					json.Unmarshal([]byte(tc.Function.Arguments), &args)
-					events <- domain.ToolCallEvent{
-						ToolCall: domain.ToolCall{
-							ID:   tc.ID,
-							Type: "function",
-							Function: domain.FunctionCall{
-								Name:      tc.Function.Name,
-								Arguments: args,
-							},
-						},
-					}
-				} else if tc.Function.Arguments != "" {
-					events <- domain.ToolCallDelta{Delta: tc.Function.Arguments}
-				}
+				toolCalls.add(events, tc.Index, tc.ID, tc.Function.Name, tc.Function.Arguments)
 			}
 
 			if chunk.Choices[0].FinishReason != "" {
+				toolCalls.flush(events)
 				if chunk.Choices[0].FinishReason == "tool_calls" {
 					events <- domain.FinishEvent{Reason: domain.FinishReasonToolCalls}
 				} else {
@@ -0,0 +1,15 @@
+package provider
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestAzureToolChoice(t *testing.T) {
+	recorder := &bodyRecorder{}
+	c := &AzureOpenAIClient{
+		apiKey: "key", endpoint: "https://acme.openai.azure.com", apiVersion: "2024-06-01", deployment: "gpt-4o",
+		httpClient: &http.Client{Transport: recorder},
+	}
+	checkToolChoiceSent(t, recorder, c)
+}
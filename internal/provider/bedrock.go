@@ -53,6 +53,7 @@ type BedrockClient struct {
 	// Clients
 	httpClient      *http.Client           // For Bearer token auth
 	runtimeClient   *bedrockruntime.Client // For IAM auth with true streaming
+	runtimeHTTP     *http.Client           // HTTP client of runtimeClient
 	useSDKStreaming bool                   // True if using IAM auth with AWS SDK
 
 	// Cache
@@ -88,7 +89,7 @@ func NewBedrockClient(cfg config.BedrockConfig, settings ...domain.ConnectionSet
 		// Configure HTTP transport for streaming using connection settings
 		httpClient := &http.Client{
 			Timeout: time.Duration(connSettings.RequestTimeoutSec) * time.Second,
-			Transport: &http.Transport{
+			Transport: newPoolTransport(&http.Transport{
 				DisableKeepAlives:     !connSettings.EnableKeepAlive,
 				MaxIdleConns:          connSettings.MaxIdleConnections,
 				MaxIdleConnsPerHost:   connSettings.MaxIdleConnections,
@@ -97,7 +98,7 @@ func NewBedrockClient(cfg config.BedrockConfig, settings ...domain.ConnectionSet
 				TLSHandshakeTimeout:   10 * time.Second,
 				ExpectContinueTimeout: 1 * time.Second,
 				ForceAttemptHTTP2:     connSettings.EnableHTTP2,
-			},
+			}, connSettings),
 		}
 
 		awsCfg, err := awsconfig.LoadDefaultConfig(ctx,
@@ -114,6 +115,7 @@ func NewBedrockClient(cfg config.BedrockConfig, settings ...domain.ConnectionSet
 		}
 
 		client.runtimeClient = bedrockruntime.NewFromConfig(awsCfg)
+		client.runtimeHTTP = httpClient
 		client.useSDKStreaming = true
 	} else if cfg.APIKey != "" {
 		client.apiKey = cfg.APIKey
@@ -274,7 +276,7 @@ func (c *BedrockClient) Embed(ctx context.Context, model string, texts []string,
 
 		if resp.StatusCode != http.StatusOK {
 			respBody, _ := io.ReadAll(resp.Body)
-			return nil, 0, fmt.Errorf("bedrock embed error %d: %s", resp.StatusCode, string(respBody))
+			return nil, 0, NewHTTPError(c.Provider(), resp.StatusCode, respBody)
 		}
 
 		var result struct {
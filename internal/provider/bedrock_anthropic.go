@@ -269,10 +269,7 @@ func (c *BedrockClient) anthropicSimulatedStream(ctx context.Context, req *domai
 
 		if resp.StatusCode != http.StatusOK {
 			respBody, _ := io.ReadAll(resp.Body)
-			eventChan <- domain.PolicyViolationEvent{
-				Message:  fmt.Sprintf("API error %d: %s", resp.StatusCode, string(respBody)),
-				Severity: "critical",
-			}
+			eventChan <- domain.ErrorEvent{Err: NewHTTPError(c.Provider(), resp.StatusCode, respBody)}
 			eventChan <- domain.FinishEvent{Reason: domain.FinishReasonError}
 			return
 		}
@@ -358,6 +355,9 @@ func (c *BedrockClient) buildAnthropicRequest(req *domain.ChatRequest) anthropic
 	if req.Temperature != nil {
 		anthropicReq.Temperature = req.Temperature
 	}
+	if req.TopP != nil {
+		anthropicReq.TopP = req.TopP
+	}
 
 	if req.SystemPrompt != "" {
 		anthropicReq.System = req.SystemPrompt
@@ -477,7 +477,7 @@ func (c *BedrockClient) anthropicComplete(ctx context.Context, req *domain.ChatR
 
 	if resp.StatusCode != http.StatusOK {
 		respBody, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("bedrock API error %d: %s", resp.StatusCode, string(respBody))
+		return nil, NewHTTPError(c.Provider(), resp.StatusCode, respBody)
 	}
 
 	respBody, _ := io.ReadAll(resp.Body)
@@ -667,6 +667,9 @@ func (c *BedrockClient) anthropicConverseComplete(ctx context.Context, req *doma
 	if req.Temperature != nil {
 		inferenceConfig.Temperature = req.Temperature
 	}
+	if req.TopP != nil {
+		inferenceConfig.TopP = req.TopP
+	}
 
 	// Build system prompt
 	var system []types.SystemContentBlock
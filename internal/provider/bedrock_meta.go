@@ -183,10 +183,7 @@ func (c *BedrockClient) metaSimulatedStream(ctx context.Context, req *domain.Cha
 
 		if resp.StatusCode != http.StatusOK {
 			respBody, _ := io.ReadAll(resp.Body)
-			eventChan <- domain.PolicyViolationEvent{
-				Message:  fmt.Sprintf("API error %d: %s", resp.StatusCode, string(respBody)),
-				Severity: "critical",
-			}
+			eventChan <- domain.ErrorEvent{Err: NewHTTPError(c.Provider(), resp.StatusCode, respBody)}
 			eventChan <- domain.FinishEvent{Reason: domain.FinishReasonError}
 			return
 		}
@@ -241,6 +238,10 @@ func (c *BedrockClient) buildMetaRequest(req *domain.ChatRequest) metaRequest {
 		metaReq.Temperature = *req.Temperature
 	}
 
+	if req.TopP != nil {
+		metaReq.TopP = *req.TopP
+	}
+
 	// Build prompt from messages (Meta uses Llama 3 format)
 	var prompt strings.Builder
 
@@ -306,7 +307,7 @@ func (c *BedrockClient) metaComplete(ctx context.Context, req *domain.ChatReques
 
 	if resp.StatusCode != http.StatusOK {
 		respBody, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("bedrock API error %d: %s", resp.StatusCode, string(respBody))
+		return nil, NewHTTPError(c.Provider(), resp.StatusCode, respBody)
 	}
 
 	respBody, _ := io.ReadAll(resp.Body)
@@ -181,10 +181,7 @@ func (c *BedrockClient) mistralSimulatedStream(ctx context.Context, req *domain.
 
 		if resp.StatusCode != http.StatusOK {
 			respBody, _ := io.ReadAll(resp.Body)
-			eventChan <- domain.PolicyViolationEvent{
-				Message:  fmt.Sprintf("API error %d: %s", resp.StatusCode, string(respBody)),
-				Severity: "critical",
-			}
+			eventChan <- domain.ErrorEvent{Err: NewHTTPError(c.Provider(), resp.StatusCode, respBody)}
 			eventChan <- domain.FinishEvent{Reason: domain.FinishReasonError}
 			return
 		}
@@ -242,6 +239,10 @@ func (c *BedrockClient) buildMistralRequest(req *domain.ChatRequest) mistralRequ
 		mistralReq.Temperature = *req.Temperature
 	}
 
+	if req.TopP != nil {
+		mistralReq.TopP = *req.TopP
+	}
+
 	// Build prompt from messages (Mistral uses [INST] format)
 	var prompt strings.Builder
 
@@ -312,7 +313,7 @@ func (c *BedrockClient) mistralComplete(ctx context.Context, req *domain.ChatReq
 
 	if resp.StatusCode != http.StatusOK {
 		respBody, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("bedrock API error %d: %s", resp.StatusCode, string(respBody))
+		return nil, NewHTTPError(c.Provider(), resp.StatusCode, respBody)
 	}
 
 	respBody, _ := io.ReadAll(resp.Body)
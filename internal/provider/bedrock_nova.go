@@ -160,6 +160,9 @@ func (c *BedrockClient) novaConverseStream(ctx context.Context, req *domain.Chat
 	if req.Temperature != nil {
 		inferenceConfig.Temperature = req.Temperature
 	}
+	if req.TopP != nil {
+		inferenceConfig.TopP = req.TopP
+	}
 
 	// Build system prompt
 	var system []types.SystemContentBlock
@@ -402,10 +405,7 @@ func (c *BedrockClient) novaSimulatedStream(ctx context.Context, req *domain.Cha
 
 		if resp.StatusCode != http.StatusOK {
 			respBody, _ := io.ReadAll(resp.Body)
-			eventChan <- domain.PolicyViolationEvent{
-				Message:  fmt.Sprintf("API error %d: %s", resp.StatusCode, string(respBody)),
-				Severity: "critical",
-			}
+			eventChan <- domain.ErrorEvent{Err: NewHTTPError(c.Provider(), resp.StatusCode, respBody)}
 			eventChan <- domain.FinishEvent{Reason: domain.FinishReasonError}
 			return
 		}
@@ -490,6 +490,9 @@ func (c *BedrockClient) buildNovaRequest(req *domain.ChatRequest) novaRequest {
 	if req.Temperature != nil {
 		novaReq.InferenceConfig.Temperature = req.Temperature
 	}
+	if req.TopP != nil {
+		novaReq.InferenceConfig.TopP = req.TopP
+	}
 
 	if req.SystemPrompt != "" {
 		novaReq.System = []novaSystemContent{{Text: req.SystemPrompt}}
@@ -646,7 +649,7 @@ func (c *BedrockClient) novaComplete(ctx context.Context, req *domain.ChatReques
 
 	if resp.StatusCode != http.StatusOK {
 		respBody, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("bedrock API error %d: %s", resp.StatusCode, string(respBody))
+		return nil, NewHTTPError(c.Provider(), resp.StatusCode, respBody)
 	}
 
 	respBody, _ := io.ReadAll(resp.Body)
@@ -843,6 +846,9 @@ func (c *BedrockClient) novaConverseComplete(ctx context.Context, req *domain.Ch
 	if req.Temperature != nil {
 		inferenceConfig.Temperature = req.Temperature
 	}
+	if req.TopP != nil {
+		inferenceConfig.TopP = req.TopP
+	}
 
 	// Build system prompt
 	var system []types.SystemContentBlock
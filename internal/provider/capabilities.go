@@ -0,0 +1,92 @@
+package provider
+
+import (
+	"strings"
+
+	"modelgate/internal/domain"
+)
+
+// Capability states in the table below
+const (
+	capUnknown int8 = iota
+	capYes
+	capNo
+)
+
+// knownCapabilities lists what well-known model families accept, for models
+// whose provider doesn't report it. Models are matched by the longest prefix
+// of their name; anything not listed is left unknown.
+var knownCapabilities = []struct {
+	prefix                   string
+	tools, vision, reasoning int8
+}{
+	{"gpt-3.5", capYes, capNo, capNo},
+	{"gpt-4-turbo", capYes, capYes, capNo},
+	{"gpt-4o", capYes, capYes, capNo},
+	{"gpt-4.1", capYes, capYes, capNo},
+	{"gpt-5", capYes, capYes, capYes},
+	{"o1", capYes, capYes, capYes},
+	{"o1-mini", capNo, capNo, capYes},
+	{"o1-preview", capNo, capNo, capYes},
+	{"o3", capYes, capYes, capYes},
+	{"o3-mini", capYes, capNo, capYes},
+	{"o4-mini", capYes, capYes, capYes},
+	{"claude-2", capNo, capNo, capNo},
+	{"claude-instant", capNo, capNo, capNo},
+	{"claude-3-", capYes, capYes, capNo},
+	{"claude-3-5-haiku", capYes, capUnknown, capNo},
+	{"claude-3-7-sonnet", capYes, capYes, capYes},
+	{"claude-haiku-4", capYes, capYes, capYes},
+	{"claude-sonnet-4", capYes, capYes, capYes},
+	{"claude-opus-4", capYes, capYes, capYes},
+	{"gemini-1.5", capYes, capYes, capNo},
+	{"gemini-2.0", capYes, capYes, capNo},
+	{"gemini-2.5", capYes, capYes, capYes},
+	{"grok-2-vision", capUnknown, capYes, capNo},
+	{"grok-3", capYes, capNo, capNo},
+	{"grok-3-mini", capYes, capNo, capYes},
+	{"grok-4", capYes, capYes, capYes},
+	{"grok-code", capYes, capNo, capYes},
+	{"deepseek-chat", capYes, capNo, capNo},
+	{"deepseek-reasoner", capNo, capNo, capYes},
+	{"command-r", capYes, capNo, capNo},
+	{"pixtral", capYes, capYes, capNo},
+	{"llava", capUnknown, capYes, capNo},
+	{"llama-3.2-11b-vision", capUnknown, capYes, capNo},
+	{"llama-3.2-90b-vision", capUnknown, capYes, capNo},
+}
+
+// KnownCapabilities returns what a model is known to accept from the bundled
+// table. The model may be given with a provider prefix ("openai/gpt-4o"), an
+// OpenRouter-style vendor prefix, or as a Bedrock model ID.
+func KnownCapabilities(model string) domain.ModelCapabilities {
+	name := strings.ToLower(model)
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	if i := strings.Index(name, "anthropic."); i >= 0 {
+		name = name[i+len("anthropic."):]
+	}
+
+	var caps domain.ModelCapabilities
+	best := -1
+	for _, k := range knownCapabilities {
+		if len(k.prefix) > best && strings.HasPrefix(name, k.prefix) {
+			best = len(k.prefix)
+			caps = domain.ModelCapabilities{Tools: capFlag(k.tools), Vision: capFlag(k.vision), Reasoning: capFlag(k.reasoning)}
+		}
+	}
+	return caps
+}
+
+func capFlag(state int8) *bool {
+	switch state {
+	case capYes:
+		v := true
+		return &v
+	case capNo:
+		v := false
+		return &v
+	}
+	return nil
+}
@@ -66,6 +66,10 @@ func (c *CohereClient) Provider() domain.Provider {
 	return domain.ProviderCohere
 }
 
+// SupportsDocuments reports that Cohere grounds answers in the request's
+// documents and cites them (implements domain.DocumentCapable)
+func (c *CohereClient) SupportsDocuments() bool { return true }
+
 // SupportsModel checks if a model is supported
 func (c *CohereClient) SupportsModel(model string) bool {
 	cohereModels := []string{
@@ -94,23 +98,8 @@ func (c *CohereClient) ChatStream(ctx context.Context, req *domain.ChatRequest)
 		defer close(events)
 
 		url := cohereAPIURL + "/chat"
-		messages := c.buildMessages(req)
-
-		body := map[string]any{
-			"model":    req.Model,
-			"messages": messages,
-			"stream":   true,
-		}
-
-		if req.Temperature != nil {
-			body["temperature"] = *req.Temperature
-		}
-		if req.MaxTokens != nil {
-			body["max_tokens"] = *req.MaxTokens
-		}
-		if len(req.Tools) > 0 {
-			body["tools"] = c.convertTools(req.Tools)
-		}
+		body := c.buildBody(req)
+		body["stream"] = true
 
 		jsonBody, _ := json.Marshal(body)
 		httpReq, err := http.NewRequestWithContext(ctx, "POST", url, strings.NewReader(string(jsonBody)))
@@ -128,10 +117,11 @@ func (c *CohereClient) ChatStream(ctx context.Context, req *domain.ChatRequest)
 			return
 		}
 		defer resp.Body.Close()
+		observeQuota(ctx, resp.Header)
 
 		if resp.StatusCode != http.StatusOK {
 			bodyBytes, _ := io.ReadAll(resp.Body)
-			events <- domain.TextChunk{Content: fmt.Sprintf("Cohere error: %s", string(bodyBytes))}
+			events <- domain.ErrorEvent{Err: NewHTTPError(c.Provider(), resp.StatusCode, bodyBytes)}
 			events <- domain.FinishEvent{Reason: domain.FinishReasonError}
 			return
 		}
@@ -145,22 +135,7 @@ func (c *CohereClient) ChatStream(ctx context.Context, req *domain.ChatRequest)
 // ChatComplete performs non-streaming chat completion
 func (c *CohereClient) ChatComplete(ctx context.Context, req *domain.ChatRequest) (*domain.ChatResponse, error) {
 	url := cohereAPIURL + "/chat"
-	messages := c.buildMessages(req)
-
-	body := map[string]any{
-		"model":    req.Model,
-		"messages": messages,
-	}
-
-	if req.Temperature != nil {
-		body["temperature"] = *req.Temperature
-	}
-	if req.MaxTokens != nil {
-		body["max_tokens"] = *req.MaxTokens
-	}
-	if len(req.Tools) > 0 {
-		body["tools"] = c.convertTools(req.Tools)
-	}
+	body := c.buildBody(req)
 
 	jsonBody, _ := json.Marshal(body)
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, strings.NewReader(string(jsonBody)))
@@ -176,10 +151,11 @@ func (c *CohereClient) ChatComplete(ctx context.Context, req *domain.ChatRequest
 		return nil, err
 	}
 	defer resp.Body.Close()
+	observeQuota(ctx, resp.Header)
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("Cohere API error: %s", string(bodyBytes))
+		return nil, NewHTTPError(c.Provider(), resp.StatusCode, bodyBytes)
 	}
 
 	var result struct {
@@ -188,40 +164,22 @@ func (c *CohereClient) ChatComplete(ctx context.Context, req *domain.ChatRequest
 				Type string `json:"type"`
 				Text string `json:"text"`
 			} `json:"content"`
-			ToolCalls []struct {
-				ID       string `json:"id"`
-				Type     string `json:"type"`
-				Function struct {
-					Name      string         `json:"name"`
-					Arguments map[string]any `json:"arguments"`
-				} `json:"function"`
-			} `json:"tool_calls"`
+			ToolCalls []cohereToolCall `json:"tool_calls"`
+			Citations []cohereCitation `json:"citations"`
 		} `json:"message"`
-		FinishReason string `json:"finish_reason"`
-		Usage        struct {
-			BilledUnits struct {
-				InputTokens  int32 `json:"input_tokens"`
-				OutputTokens int32 `json:"output_tokens"`
-			} `json:"billed_units"`
-			Tokens struct {
-				InputTokens  int32 `json:"input_tokens"`
-				OutputTokens int32 `json:"output_tokens"`
-			} `json:"tokens"`
-		} `json:"usage"`
+		FinishReason string      `json:"finish_reason"`
+		Usage        cohereUsage `json:"usage"`
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return nil, err
 	}
 
+	usage := result.Usage.event()
 	response := &domain.ChatResponse{
 		Model:        req.Model,
-		FinishReason: domain.FinishReason(result.FinishReason),
-		Usage: &domain.UsageEvent{
-			PromptTokens:     result.Usage.Tokens.InputTokens,
-			CompletionTokens: result.Usage.Tokens.OutputTokens,
-			TotalTokens:      result.Usage.Tokens.InputTokens + result.Usage.Tokens.OutputTokens,
-		},
+		FinishReason: cohereFinishReason(result.FinishReason),
+		Usage:        &usage,
 	}
 
 	// Extract text content
@@ -233,14 +191,13 @@ func (c *CohereClient) ChatComplete(ctx context.Context, req *domain.ChatRequest
 
 	// Extract tool calls
 	for _, tc := range result.Message.ToolCalls {
-		response.ToolCalls = append(response.ToolCalls, domain.ToolCall{
-			ID:   tc.ID,
-			Type: tc.Type,
-			Function: domain.FunctionCall{
-				Name:      tc.Function.Name,
-				Arguments: tc.Function.Arguments,
-			},
-		})
+		response.ToolCalls = append(response.ToolCalls, tc.toolCall())
+	}
+
+	for _, citation := range result.Message.Citations {
+		if citation.Type == "" || citation.Type == "TEXT_CONTENT" {
+			response.Citations = append(response.Citations, citation.citation())
+		}
 	}
 
 	return response, nil
@@ -274,10 +231,11 @@ func (c *CohereClient) Embed(ctx context.Context, model string, texts []string,
 		return nil, 0, err
 	}
 	defer resp.Body.Close()
+	observeQuota(ctx, resp.Header)
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, 0, fmt.Errorf("Cohere API error: %s", string(bodyBytes))
+		return nil, 0, NewHTTPError(c.Provider(), resp.StatusCode, bodyBytes)
 	}
 
 	var result struct {
@@ -379,6 +337,59 @@ func (c *CohereClient) ListModels(ctx context.Context) ([]domain.ModelInfo, erro
 
 // Helper methods
 
+// buildBody builds the request body of the v2 chat endpoint
+func (c *CohereClient) buildBody(req *domain.ChatRequest) map[string]any {
+	body := map[string]any{
+		"model":    req.Model,
+		"messages": c.buildMessages(req),
+	}
+
+	if req.Temperature != nil {
+		body["temperature"] = *req.Temperature
+	}
+	if req.TopP != nil {
+		body["p"] = *req.TopP
+	}
+	if req.MaxTokens != nil {
+		body["max_tokens"] = *req.MaxTokens
+	}
+	if len(req.Tools) > 0 {
+		body["tools"] = c.convertTools(req.Tools)
+		// Cohere can require a tool call or forbid one, but not pick the
+		// function: a forced function is sent as the only tool
+		if tc := req.ToolChoice; tc != nil {
+			switch tc.Mode {
+			case "required":
+				body["tool_choice"] = "REQUIRED"
+				if tc.Function != "" {
+					for _, tool := range req.Tools {
+						if tool.Function.Name == tc.Function {
+							body["tools"] = c.convertTools([]domain.Tool{tool})
+						}
+					}
+				}
+			case "none":
+				body["tool_choice"] = "NONE"
+			}
+		}
+	}
+	if len(req.Documents) > 0 {
+		docs := make([]map[string]any, len(req.Documents))
+		for i, doc := range req.Documents {
+			data := map[string]any{"text": doc.Text}
+			for k, v := range doc.AdditionalProps {
+				data[k] = v
+			}
+			docs[i] = map[string]any{"data": data}
+			if doc.ID != "" {
+				docs[i]["id"] = doc.ID
+			}
+		}
+		body["documents"] = docs
+	}
+	return body
+}
+
 func (c *CohereClient) buildMessages(req *domain.ChatRequest) []map[string]any {
 	messages := make([]map[string]any, 0)
 
@@ -393,27 +404,27 @@ func (c *CohereClient) buildMessages(req *domain.ChatRequest) []map[string]any {
 		m := map[string]any{"role": msg.Role}
 
 		// Cohere expects content as string or array
-		if len(msg.Content) == 1 && msg.Content[0].Type == "text" {
-			m["content"] = msg.Content[0].Text
-		} else {
-			var textContent strings.Builder
-			for _, block := range msg.Content {
-				if block.Type == "text" {
-					textContent.WriteString(block.Text)
-				}
+		var textContent strings.Builder
+		for _, block := range msg.Content {
+			if block.Type == "text" {
+				textContent.WriteString(block.Text)
 			}
+		}
+		// An assistant turn that only calls tools has no content
+		if textContent.Len() > 0 || len(msg.ToolCalls) == 0 {
 			m["content"] = textContent.String()
 		}
 
 		if len(msg.ToolCalls) > 0 {
 			toolCalls := make([]map[string]any, len(msg.ToolCalls))
 			for i, tc := range msg.ToolCalls {
+				args, _ := json.Marshal(tc.Function.Arguments)
 				toolCalls[i] = map[string]any{
 					"id":   tc.ID,
 					"type": "function",
 					"function": map[string]any{
 						"name":      tc.Function.Name,
-						"arguments": tc.Function.Arguments,
+						"arguments": string(args),
 					},
 				}
 			}
@@ -445,9 +456,92 @@ func (c *CohereClient) convertTools(tools []domain.Tool) []map[string]any {
 	return result
 }
 
+// cohereToolCall is a tool call in a v2 response; arguments are a JSON string
+type cohereToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+func (tc cohereToolCall) toolCall() domain.ToolCall {
+	args := map[string]any{}
+	json.Unmarshal([]byte(tc.Function.Arguments), &args)
+	return domain.ToolCall{
+		ID:       tc.ID,
+		Type:     "function",
+		Function: domain.FunctionCall{Name: tc.Function.Name, Arguments: args},
+	}
+}
+
+// cohereCitation is a span of the response grounded in documents or tool
+// results. Citations of type PLAN cite the tool plan, not the content.
+type cohereCitation struct {
+	Start   int    `json:"start"`
+	End     int    `json:"end"`
+	Text    string `json:"text"`
+	Type    string `json:"type"`
+	Sources []struct {
+		Type string `json:"type"` // document or tool
+		ID   string `json:"id"`
+	} `json:"sources"`
+}
+
+func (c cohereCitation) citation() domain.Citation {
+	citation := domain.Citation{Start: c.Start, End: c.End, Text: c.Text, Sources: []string{}}
+	for _, src := range c.Sources {
+		citation.Sources = append(citation.Sources, src.ID)
+	}
+	return citation
+}
+
+// cohereUsage holds the tokens Cohere billed, and the raw token counts that
+// also include its prompt template
+type cohereUsage struct {
+	BilledUnits struct {
+		InputTokens  float64 `json:"input_tokens"`
+		OutputTokens float64 `json:"output_tokens"`
+	} `json:"billed_units"`
+	Tokens struct {
+		InputTokens  float64 `json:"input_tokens"`
+		OutputTokens float64 `json:"output_tokens"`
+	} `json:"tokens"`
+}
+
+// event reports the billed tokens, which cost is computed from, falling back
+// to the raw counts when billing units are missing
+func (u cohereUsage) event() domain.UsageEvent {
+	input, output := u.BilledUnits.InputTokens, u.BilledUnits.OutputTokens
+	if input == 0 && output == 0 {
+		input, output = u.Tokens.InputTokens, u.Tokens.OutputTokens
+	}
+	return domain.UsageEvent{
+		PromptTokens:     int32(input),
+		CompletionTokens: int32(output),
+		TotalTokens:      int32(input + output),
+	}
+}
+
+// cohereFinishReason maps a v2 finish reason
+func cohereFinishReason(reason string) domain.FinishReason {
+	switch reason {
+	case "TOOL_CALL":
+		return domain.FinishReasonToolCalls
+	case "MAX_TOKENS":
+		return domain.FinishReasonLength
+	case "ERROR", "ERROR_TOXIC", "ERROR_LIMIT":
+		return domain.FinishReasonError
+	default:
+		return domain.FinishReasonStop
+	}
+}
+
 func (c *CohereClient) processSSEStream(body io.Reader, events chan<- domain.StreamEvent) {
 	scanner := bufio.NewScanner(body)
-	var inputTokens, outputTokens int32
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	var toolCalls toolCallStream
 
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -459,19 +553,18 @@ func (c *CohereClient) processSSEStream(body io.Reader, events chan<- domain.Str
 
 		var event struct {
 			Type  string `json:"type"`
+			Index int    `json:"index"`
 			Delta struct {
 				Message struct {
 					Content struct {
 						Text string `json:"text"`
 					} `json:"content"`
+					ToolCalls cohereToolCall `json:"tool_calls"`
+					Citations cohereCitation `json:"citations"`
 				} `json:"message"`
+				FinishReason string      `json:"finish_reason"`
+				Usage        cohereUsage `json:"usage"`
 			} `json:"delta"`
-			Usage struct {
-				Tokens struct {
-					InputTokens  int32 `json:"input_tokens"`
-					OutputTokens int32 `json:"output_tokens"`
-				} `json:"tokens"`
-			} `json:"usage"`
 		}
 
 		if err := json.Unmarshal([]byte(data), &event); err != nil {
@@ -483,15 +576,17 @@ func (c *CohereClient) processSSEStream(body io.Reader, events chan<- domain.Str
 			if event.Delta.Message.Content.Text != "" {
 				events <- domain.TextChunk{Content: event.Delta.Message.Content.Text}
 			}
-		case "message-end":
-			inputTokens = event.Usage.Tokens.InputTokens
-			outputTokens = event.Usage.Tokens.OutputTokens
-			events <- domain.UsageEvent{
-				PromptTokens:     inputTokens,
-				CompletionTokens: outputTokens,
-				TotalTokens:      inputTokens + outputTokens,
+		case "tool-call-start", "tool-call-delta":
+			tc := event.Delta.Message.ToolCalls
+			toolCalls.add(events, event.Index, tc.ID, tc.Function.Name, tc.Function.Arguments)
+		case "citation-start":
+			if citation := event.Delta.Message.Citations; citation.Type == "" || citation.Type == "TEXT_CONTENT" {
+				events <- domain.CitationEvent{Citation: citation.citation()}
 			}
-			events <- domain.FinishEvent{Reason: domain.FinishReasonStop}
+		case "message-end":
+			toolCalls.flush(events)
+			events <- event.Delta.Usage.event()
+			events <- domain.FinishEvent{Reason: cohereFinishReason(event.Delta.FinishReason)}
 			return
 		}
 	}
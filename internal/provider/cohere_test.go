@@ -0,0 +1,75 @@
+package provider
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"modelgate/internal/domain"
+)
+
+func TestCohereStream(t *testing.T) {
+	stream := strings.Join([]string{
+		`event: message-start`,
+		`data: {"type":"message-start","id":"m1","delta":{"message":{"role":"assistant"}}}`,
+		`data: {"type":"tool-call-start","index":0,"delta":{"message":{"tool_calls":{"id":"get_weather_1","type":"function","function":{"name":"get_weather","arguments":""}}}}}`,
+		`data: {"type":"tool-call-delta","index":0,"delta":{"message":{"tool_calls":{"function":{"arguments":"{\"city\":\"Paris\"}"}}}}}`,
+		`data: {"type":"tool-call-end","index":0}`,
+		`data: {"type":"content-delta","index":0,"delta":{"message":{"content":{"text":"It is sunny."}}}}`,
+		`data: {"type":"citation-start","index":0,"delta":{"message":{"citations":{"start":6,"end":11,"text":"sunny","sources":[{"type":"document","id":"doc_1"}],"type":"TEXT_CONTENT"}}}}`,
+		`data: {"type":"citation-start","index":1,"delta":{"message":{"citations":{"start":0,"end":4,"text":"I'll","sources":[],"type":"PLAN"}}}}`,
+		`data: {"type":"message-end","delta":{"finish_reason":"TOOL_CALL","usage":{"billed_units":{"input_tokens":20,"output_tokens":8},"tokens":{"input_tokens":520,"output_tokens":12}}}}`,
+	}, "\n\n") + "\n\n"
+	events := collect(func(events chan<- domain.StreamEvent) {
+		(&CohereClient{}).processSSEStream(strings.NewReader(stream), events)
+	})
+
+	want := []domain.StreamEvent{
+		domain.ToolCallDelta{Index: 0, ID: "get_weather_1", Name: "get_weather"},
+		domain.ToolCallDelta{Index: 0, Delta: `{"city":"Paris"}`},
+		domain.TextChunk{Content: "It is sunny."},
+		domain.CitationEvent{Citation: domain.Citation{Start: 6, End: 11, Text: "sunny", Sources: []string{"doc_1"}}},
+		domain.ToolCallEvent{Streamed: true, ToolCall: domain.ToolCall{ID: "get_weather_1", Type: "function",
+			Function: domain.FunctionCall{Name: "get_weather", Arguments: map[string]any{"city": "Paris"}}}},
+		// Billed tokens, not the raw counts with the prompt template
+		domain.UsageEvent{PromptTokens: 20, CompletionTokens: 8, TotalTokens: 28},
+		domain.FinishEvent{Reason: domain.FinishReasonToolCalls},
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Fatalf("expected %+v, got %+v", want, events)
+	}
+}
+
+func TestCohereRequestBody(t *testing.T) {
+	weather := domain.Tool{Type: "function", Function: domain.FunctionDefinition{Name: "get_weather"}}
+	search := domain.Tool{Type: "function", Function: domain.FunctionDefinition{Name: "search"}}
+	req := &domain.ChatRequest{
+		Model:      "command-r-plus",
+		Tools:      []domain.Tool{weather, search},
+		ToolChoice: &domain.ToolChoice{Mode: "required", Function: "search"},
+		Documents:  []domain.Document{{ID: "doc_1", Text: "Paris is sunny", AdditionalProps: map[string]string{"title": "Forecast"}}},
+		Messages: []domain.Message{
+			{Role: "user", Content: []domain.ContentBlock{{Type: "text", Text: "Weather?"}}},
+			{Role: "assistant", ToolCalls: []domain.ToolCall{{ID: "c1", Function: domain.FunctionCall{Name: "get_weather", Arguments: map[string]any{"city": "Paris"}}}}},
+			{Role: "tool", ToolCallID: "c1", Content: []domain.ContentBlock{{Type: "text", Text: "sunny"}}},
+		},
+	}
+	body := (&CohereClient{}).buildBody(req)
+
+	// A forced function is the only tool offered
+	if body["tool_choice"] != "REQUIRED" || len(body["tools"].([]map[string]any)) != 1 {
+		t.Fatalf("unexpected tool choice %v with tools %v", body["tool_choice"], body["tools"])
+	}
+	docs := body["documents"].([]map[string]any)
+	if docs[0]["id"] != "doc_1" || !reflect.DeepEqual(docs[0]["data"], map[string]any{"text": "Paris is sunny", "title": "Forecast"}) {
+		t.Fatalf("unexpected documents %v", docs)
+	}
+	messages := body["messages"].([]map[string]any)
+	if _, ok := messages[1]["content"]; ok {
+		t.Fatal("expected no content on a turn that only calls tools")
+	}
+	call := messages[1]["tool_calls"].([]map[string]any)[0]["function"].(map[string]any)
+	if call["arguments"] != `{"city":"Paris"}` {
+		t.Fatalf("expected arguments as a JSON string, got %#v", call["arguments"])
+	}
+}
@@ -0,0 +1,510 @@
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"modelgate/internal/domain"
+)
+
+// CustomClient implements the LLMClient interface for models an admin
+// registered on their own OpenAI-compatible servers (vLLM, TGI, llama.cpp and
+// the like). Each model has its own endpoint; models are addressed as
+// "custom/<model>".
+type CustomClient struct {
+	apiKey     string // Optional; sent as a bearer token to every endpoint
+	httpClient *http.Client
+	models     map[string]domain.CustomModel // By model ID
+}
+
+// NewCustomClient creates a client for the given registered models. Disabled
+// models are left out.
+func NewCustomClient(apiKey string, models []domain.CustomModel, settings ...domain.ConnectionSettings) (*CustomClient, error) {
+	connSettings := domain.DefaultConnectionSettings()
+	if len(settings) > 0 {
+		connSettings = settings[0]
+	}
+
+	byID := make(map[string]domain.CustomModel, len(models))
+	for _, m := range models {
+		if m.Enabled {
+			byID[m.ModelID] = m
+		}
+	}
+	return &CustomClient{
+		apiKey:     apiKey,
+		httpClient: BuildHTTPClient(connSettings),
+		models:     byID,
+	}, nil
+}
+
+// Provider returns the provider type
+func (c *CustomClient) Provider() domain.Provider {
+	return domain.ProviderCustom
+}
+
+// SupportsModel checks if a model is registered
+func (c *CustomClient) SupportsModel(model string) bool {
+	_, ok := c.models[c.modelID(model)]
+	return ok
+}
+
+// modelID strips the "custom/" prefix. Registered IDs may contain slashes
+// themselves, so only that prefix is removed.
+func (c *CustomClient) modelID(model string) string {
+	return strings.TrimPrefix(model, string(domain.ProviderCustom)+"/")
+}
+
+// model returns the registered model a request is for, and rejects requests
+// using capabilities the model wasn't declared with
+func (c *CustomClient) model(req *domain.ChatRequest) (*domain.CustomModel, error) {
+	m, ok := c.models[c.modelID(req.Model)]
+	if !ok {
+		return nil, domain.NewProviderError(domain.ProviderCustom, domain.ErrorCodeModelNotFound,
+			fmt.Sprintf("custom model %s is not registered", c.modelID(req.Model)))
+	}
+	if len(req.Tools) > 0 && !m.SupportsTools {
+		return nil, domain.NewProviderError(domain.ProviderCustom, domain.ErrorCodeInvalidRequest,
+			fmt.Sprintf("model %s does not support tools", m.ModelID))
+	}
+	if !m.SupportsVision && hasImages(req) {
+		return nil, domain.NewProviderError(domain.ProviderCustom, domain.ErrorCodeInvalidRequest,
+			fmt.Sprintf("model %s does not support images", m.ModelID))
+	}
+	return &m, nil
+}
+
+func hasImages(req *domain.ChatRequest) bool {
+	for _, msg := range req.Messages {
+		if messageHasImages(msg) {
+			return true
+		}
+	}
+	return false
+}
+
+func messageHasImages(msg domain.Message) bool {
+	for _, block := range msg.Content {
+		if block.Type == "image" {
+			return true
+		}
+	}
+	return false
+}
+
+// ChatStream performs streaming chat completion. Models registered without
+// streaming support are called without it and their response is replayed as
+// a stream.
+func (c *CustomClient) ChatStream(ctx context.Context, req *domain.ChatRequest) (<-chan domain.StreamEvent, error) {
+	m, err := c.model(req)
+	if err != nil {
+		return nil, err
+	}
+	events := make(chan domain.StreamEvent, 100)
+
+	if !m.SupportsStreaming {
+		go func() {
+			defer close(events)
+			resp, err := c.complete(ctx, m, req)
+			if err != nil {
+				events <- domain.ErrorEvent{Err: NormalizeError(domain.ProviderCustom, err)}
+				events <- domain.FinishEvent{Reason: domain.FinishReasonError}
+				return
+			}
+			if resp.Content != "" {
+				events <- domain.TextChunk{Content: resp.Content}
+			}
+			for _, tc := range resp.ToolCalls {
+				events <- domain.ToolCallEvent{ToolCall: tc}
+			}
+			events <- *resp.Usage
+			events <- domain.FinishEvent{Reason: resp.FinishReason}
+		}()
+		return events, nil
+	}
+
+	go func() {
+		defer close(events)
+
+		body := c.buildRequest(m, req)
+		body["stream"] = true
+		body["stream_options"] = map[string]any{"include_usage": true}
+
+		httpReq, err := c.newRequest(ctx, m, body)
+		if err != nil {
+			events <- domain.FinishEvent{Reason: domain.FinishReasonError}
+			return
+		}
+
+		resp, err := c.httpClient.Do(httpReq)
+		if err != nil {
+			events <- domain.ErrorEvent{Err: NormalizeError(domain.ProviderCustom, err)}
+			events <- domain.FinishEvent{Reason: domain.FinishReasonError}
+			return
+		}
+		defer resp.Body.Close()
+		observeQuota(ctx, resp.Header)
+
+		if resp.StatusCode != http.StatusOK {
+			bodyBytes, _ := io.ReadAll(resp.Body)
+			events <- domain.ErrorEvent{Err: NewHTTPError(c.Provider(), resp.StatusCode, bodyBytes)}
+			events <- domain.FinishEvent{Reason: domain.FinishReasonError}
+			return
+		}
+
+		c.processSSEStream(resp.Body, events)
+	}()
+
+	return events, nil
+}
+
+// ChatComplete performs non-streaming chat completion
+func (c *CustomClient) ChatComplete(ctx context.Context, req *domain.ChatRequest) (*domain.ChatResponse, error) {
+	m, err := c.model(req)
+	if err != nil {
+		return nil, err
+	}
+	return c.complete(ctx, m, req)
+}
+
+func (c *CustomClient) complete(ctx context.Context, m *domain.CustomModel, req *domain.ChatRequest) (*domain.ChatResponse, error) {
+	httpReq, err := c.newRequest(ctx, m, c.buildRequest(m, req))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	observeQuota(ctx, resp.Header)
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, NewHTTPError(c.Provider(), resp.StatusCode, bodyBytes)
+	}
+
+	var result struct {
+		Choices []struct {
+			Message struct {
+				Content          string `json:"content"`
+				ReasoningContent string `json:"reasoning_content"`
+				ToolCalls        []struct {
+					ID       string `json:"id"`
+					Type     string `json:"type"`
+					Function struct {
+						Name      string `json:"name"`
+						Arguments string `json:"arguments"`
+					} `json:"function"`
+				} `json:"tool_calls"`
+			} `json:"message"`
+			FinishReason string `json:"finish_reason"`
+		} `json:"choices"`
+		Usage struct {
+			PromptTokens     int32 `json:"prompt_tokens"`
+			CompletionTokens int32 `json:"completion_tokens"`
+			TotalTokens      int32 `json:"total_tokens"`
+		} `json:"usage"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	response := &domain.ChatResponse{
+		Model:        req.Model,
+		FinishReason: domain.FinishReasonStop,
+		Usage: &domain.UsageEvent{
+			PromptTokens:     result.Usage.PromptTokens,
+			CompletionTokens: result.Usage.CompletionTokens,
+			TotalTokens:      result.Usage.TotalTokens,
+		},
+	}
+
+	if len(result.Choices) > 0 {
+		choice := result.Choices[0]
+		response.Content = choice.Message.Content
+		response.Thinking = choice.Message.ReasoningContent
+		response.FinishReason = customFinishReason(choice.FinishReason)
+
+		for _, tc := range choice.Message.ToolCalls {
+			var args map[string]any
+			json.Unmarshal([]byte(tc.Function.Arguments), &args)
+			response.ToolCalls = append(response.ToolCalls, domain.ToolCall{
+				ID:   tc.ID,
+				Type: "function",
+				Function: domain.FunctionCall{
+					Name:      tc.Function.Name,
+					Arguments: args,
+				},
+			})
+		}
+	}
+
+	return response, nil
+}
+
+func customFinishReason(reason string) domain.FinishReason {
+	switch reason {
+	case "tool_calls":
+		return domain.FinishReasonToolCalls
+	case "length":
+		return domain.FinishReasonLength
+	default:
+		return domain.FinishReasonStop
+	}
+}
+
+// Embed generates embeddings (custom models are registered for chat only)
+func (c *CustomClient) Embed(ctx context.Context, model string, texts []string, dimensions *int32) ([][]float32, int64, error) {
+	return nil, 0, fmt.Errorf("custom models do not support embeddings")
+}
+
+// CountTokens estimates tokens in a request
+func (c *CustomClient) CountTokens(ctx context.Context, req *domain.ChatRequest) (int32, error) {
+	var total int32
+	for _, msg := range req.Messages {
+		for _, block := range msg.Content {
+			if block.Type == "text" {
+				total += int32(len(block.Text) / 4)
+			}
+		}
+	}
+	return total, nil
+}
+
+// ListModels lists the registered models with their declared limits and pricing
+func (c *CustomClient) ListModels(ctx context.Context) ([]domain.ModelInfo, error) {
+	models := make([]domain.ModelInfo, 0, len(c.models))
+	for _, m := range c.models {
+		models = append(models, m.ModelInfo())
+	}
+	sort.Slice(models, func(i, j int) bool { return models[i].ID < models[j].ID })
+	return models, nil
+}
+
+// Helper methods
+
+// newRequest builds a chat completions request against the model's endpoint
+func (c *CustomClient) newRequest(ctx context.Context, m *domain.CustomModel, body map[string]any) (*http.Request, error) {
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	url := strings.TrimSuffix(m.BaseURL, "/") + "/chat/completions"
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	return httpReq, nil
+}
+
+func (c *CustomClient) buildRequest(m *domain.CustomModel, req *domain.ChatRequest) map[string]any {
+	body := map[string]any{
+		"model":    m.Upstream(),
+		"messages": openAICompatibleMessages(req),
+	}
+
+	if req.Temperature != nil {
+		body["temperature"] = *req.Temperature
+	}
+	if req.TopP != nil {
+		body["top_p"] = *req.TopP
+	}
+	if req.MaxTokens != nil {
+		body["max_tokens"] = *req.MaxTokens
+	}
+	if len(req.Tools) > 0 {
+		tools := make([]map[string]any, len(req.Tools))
+		for i, tool := range req.Tools {
+			tools[i] = map[string]any{
+				"type": "function",
+				"function": map[string]any{
+					"name":        tool.Function.Name,
+					"description": tool.Function.Description,
+					"parameters":  tool.Function.Parameters,
+				},
+			}
+		}
+		body["tools"] = tools
+		if tc := openAIToolChoice(req.ToolChoice); tc != nil {
+			body["tool_choice"] = tc
+		}
+	}
+	if rf := openAIResponseFormat(req.ResponseFormat); rf != nil {
+		body["response_format"] = rf
+	}
+
+	return body
+}
+
+// openAICompatibleMessages converts a request's messages for OpenAI-compatible
+// servers, sending images as image_url parts
+func openAICompatibleMessages(req *domain.ChatRequest) []map[string]any {
+	messages := make([]map[string]any, 0, len(req.Messages)+1)
+
+	if req.SystemPrompt != "" {
+		messages = append(messages, map[string]any{
+			"role":    "system",
+			"content": req.SystemPrompt,
+		})
+	}
+
+	for _, msg := range req.Messages {
+		m := map[string]any{"role": msg.Role}
+
+		if messageHasImages(msg) {
+			var content []map[string]any
+			for _, block := range msg.Content {
+				switch block.Type {
+				case "text":
+					content = append(content, map[string]any{"type": "text", "text": block.Text})
+				case "image":
+					content = append(content, map[string]any{
+						"type":      "image_url",
+						"image_url": map[string]string{"url": block.ImageURL},
+					})
+				}
+			}
+			m["content"] = content
+		} else {
+			var text strings.Builder
+			for _, block := range msg.Content {
+				if block.Type == "text" {
+					text.WriteString(block.Text)
+				}
+			}
+			m["content"] = text.String()
+		}
+
+		if len(msg.ToolCalls) > 0 {
+			toolCalls := make([]map[string]any, len(msg.ToolCalls))
+			for i, tc := range msg.ToolCalls {
+				args, _ := json.Marshal(tc.Function.Arguments)
+				toolCalls[i] = map[string]any{
+					"id":   tc.ID,
+					"type": "function",
+					"function": map[string]any{
+						"name":      tc.Function.Name,
+						"arguments": string(args),
+					},
+				}
+			}
+			m["tool_calls"] = toolCalls
+		}
+
+		if msg.ToolCallID != "" {
+			m["tool_call_id"] = msg.ToolCallID
+		}
+
+		messages = append(messages, m)
+	}
+
+	return messages
+}
+
+// processSSEStream reads OpenAI-style SSE chunks. Tool call fragments are
+// passed on as deltas and accumulated by index; reasoning models may stream
+// reasoning_content.
+func (c *CustomClient) processSSEStream(body io.Reader, events chan<- domain.StreamEvent) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var inputTokens, outputTokens int32
+	var finishReason domain.FinishReason
+	var toolCalls toolCallStream
+
+	finish := func() {
+		toolCalls.flush(events)
+		events <- domain.UsageEvent{
+			PromptTokens:     inputTokens,
+			CompletionTokens: outputTokens,
+			TotalTokens:      inputTokens + outputTokens,
+		}
+		if finishReason == "" {
+			finishReason = domain.FinishReasonStop
+		}
+		events <- domain.FinishEvent{Reason: finishReason}
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			finish()
+			return
+		}
+
+		var chunk struct {
+			Choices []struct {
+				Delta struct {
+					Content          string `json:"content"`
+					ReasoningContent string `json:"reasoning_content"`
+					ToolCalls        []struct {
+						Index    int    `json:"index"`
+						ID       string `json:"id"`
+						Function struct {
+							Name      string `json:"name"`
+							Arguments string `json:"arguments"`
+						} `json:"function"`
+					} `json:"tool_calls"`
+				} `json:"delta"`
+				FinishReason *string `json:"finish_reason"`
+			} `json:"choices"`
+			Usage *struct {
+				PromptTokens     int32 `json:"prompt_tokens"`
+				CompletionTokens int32 `json:"completion_tokens"`
+			} `json:"usage"`
+		}
+
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			continue
+		}
+
+		if chunk.Usage != nil {
+			inputTokens = chunk.Usage.PromptTokens
+			outputTokens = chunk.Usage.CompletionTokens
+		}
+
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+
+		delta := chunk.Choices[0].Delta
+		if delta.ReasoningContent != "" {
+			events <- domain.ThinkingChunk{Content: delta.ReasoningContent}
+		}
+		if delta.Content != "" {
+			events <- domain.TextChunk{Content: delta.Content}
+		}
+
+		for _, tc := range delta.ToolCalls {
+			toolCalls.add(events, tc.Index, tc.ID, tc.Function.Name, tc.Function.Arguments)
+		}
+
+		// TGI sends a null finish_reason on every chunk but the last
+		if fr := chunk.Choices[0].FinishReason; fr != nil && *fr != "" {
+			finishReason = customFinishReason(*fr)
+		}
+	}
+
+	finish()
+}
@@ -0,0 +1,467 @@
+// Package provider implements LLM provider clients.
+package provider
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"modelgate/internal/domain"
+)
+
+const deepseekAPIURL = "https://api.deepseek.com"
+
+// DeepSeekConfig contains DeepSeek-specific settings
+type DeepSeekConfig struct {
+	APIKey             string
+	BaseURL            string // Optional override of the API URL
+	ConnectionSettings domain.ConnectionSettings
+}
+
+// DeepSeekClient implements the LLMClient interface for DeepSeek models.
+// The DeepSeek API is OpenAI-compatible; models are addressed as
+// "deepseek/<model>". Prompt prefixes DeepSeek has seen recently are served
+// from its context cache, reported as prompt_cache_hit_tokens and billed at
+// the cached input price.
+type DeepSeekClient struct {
+	apiKey     string
+	baseURL    string
+	httpClient *http.Client
+	modelCache map[string]string // Cache of model aliases to native model IDs
+}
+
+// deepseekModels is the DeepSeek model list with DeepSeek's published pricing
+// (USD per 1M tokens; cached input is the context cache hit price)
+var deepseekModels = []domain.ModelInfo{
+	{ID: "deepseek-chat", Name: "DeepSeek Chat", SupportsTools: true, ContextLimit: 65536, OutputLimit: 8192,
+		InputCostPer1M: 0.27, OutputCostPer1M: 1.10, CachedInputCostPer1M: 0.07},
+	{ID: "deepseek-reasoner", Name: "DeepSeek Reasoner", SupportsReasoning: true, ContextLimit: 65536, OutputLimit: 65536,
+		InputCostPer1M: 0.55, OutputCostPer1M: 2.19, CachedInputCostPer1M: 0.14},
+}
+
+// NewDeepSeekClient creates a new DeepSeek client
+func NewDeepSeekClient(cfg DeepSeekConfig) (*DeepSeekClient, error) {
+	if cfg.APIKey == "" {
+		return nil, fmt.Errorf("DeepSeek API key is required")
+	}
+
+	connSettings := cfg.ConnectionSettings
+	if connSettings.MaxConnections == 0 {
+		connSettings = domain.DefaultConnectionSettings()
+	}
+
+	baseURL := strings.TrimSuffix(cfg.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = deepseekAPIURL
+	}
+
+	return &DeepSeekClient{
+		apiKey:     cfg.APIKey,
+		baseURL:    baseURL,
+		httpClient: BuildHTTPClient(connSettings),
+		modelCache: make(map[string]string),
+	}, nil
+}
+
+// SetModelCache sets the model cache (implements ModelCacheable)
+func (c *DeepSeekClient) SetModelCache(cache map[string]string) {
+	c.modelCache = cache
+}
+
+// GetModelCache returns the model cache (implements ModelCacheable)
+func (c *DeepSeekClient) GetModelCache() map[string]string {
+	return c.modelCache
+}
+
+// resolveModelID resolves a model ID using the cache if available
+func (c *DeepSeekClient) resolveModelID(model string) string {
+	if c.modelCache != nil {
+		if nativeID, ok := c.modelCache[model]; ok {
+			return ExtractModelID(nativeID)
+		}
+	}
+	return ExtractModelID(model)
+}
+
+// Provider returns the provider type
+func (c *DeepSeekClient) Provider() domain.Provider {
+	return domain.ProviderDeepSeek
+}
+
+// SupportsModel checks if a model is supported
+func (c *DeepSeekClient) SupportsModel(model string) bool {
+	return strings.HasPrefix(strings.ToLower(c.resolveModelID(model)), "deepseek")
+}
+
+// ChatStream performs streaming chat completion
+func (c *DeepSeekClient) ChatStream(ctx context.Context, req *domain.ChatRequest) (<-chan domain.StreamEvent, error) {
+	events := make(chan domain.StreamEvent, 100)
+
+	go func() {
+		defer close(events)
+
+		body := c.buildRequest(req)
+		body["stream"] = true
+		body["stream_options"] = map[string]any{"include_usage": true}
+
+		httpReq, err := c.newRequest(ctx, "POST", "/chat/completions", body)
+		if err != nil {
+			events <- domain.FinishEvent{Reason: domain.FinishReasonError}
+			return
+		}
+
+		resp, err := c.httpClient.Do(httpReq)
+		if err != nil {
+			events <- domain.FinishEvent{Reason: domain.FinishReasonError}
+			return
+		}
+		defer resp.Body.Close()
+		observeQuota(ctx, resp.Header)
+
+		if resp.StatusCode != http.StatusOK {
+			bodyBytes, _ := io.ReadAll(resp.Body)
+			events <- domain.ErrorEvent{Err: NewHTTPError(c.Provider(), resp.StatusCode, bodyBytes)}
+			events <- domain.FinishEvent{Reason: domain.FinishReasonError}
+			return
+		}
+
+		c.processSSEStream(resp.Body, events)
+	}()
+
+	return events, nil
+}
+
+// ChatComplete performs non-streaming chat completion
+func (c *DeepSeekClient) ChatComplete(ctx context.Context, req *domain.ChatRequest) (*domain.ChatResponse, error) {
+	httpReq, err := c.newRequest(ctx, "POST", "/chat/completions", c.buildRequest(req))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	observeQuota(ctx, resp.Header)
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, NewHTTPError(c.Provider(), resp.StatusCode, bodyBytes)
+	}
+
+	var result struct {
+		Choices []struct {
+			Message struct {
+				Content          string `json:"content"`
+				ReasoningContent string `json:"reasoning_content"`
+				ToolCalls        []struct {
+					ID       string `json:"id"`
+					Type     string `json:"type"`
+					Function struct {
+						Name      string `json:"name"`
+						Arguments string `json:"arguments"`
+					} `json:"function"`
+				} `json:"tool_calls"`
+			} `json:"message"`
+			FinishReason string `json:"finish_reason"`
+		} `json:"choices"`
+		Usage deepseekUsage `json:"usage"`
+		Model string        `json:"model"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	response := &domain.ChatResponse{
+		Model: result.Model,
+		Usage: result.Usage.event(),
+	}
+
+	if len(result.Choices) > 0 {
+		choice := result.Choices[0]
+		response.Content = choice.Message.Content
+		response.Thinking = choice.Message.ReasoningContent
+		response.FinishReason = domain.FinishReason(choice.FinishReason)
+
+		for _, tc := range choice.Message.ToolCalls {
+			var args map[string]any
+			json.Unmarshal([]byte(tc.Function.Arguments), &args)
+			response.ToolCalls = append(response.ToolCalls, domain.ToolCall{
+				ID:   tc.ID,
+				Type: tc.Type,
+				Function: domain.FunctionCall{
+					Name:      tc.Function.Name,
+					Arguments: args,
+				},
+			})
+		}
+	}
+
+	return response, nil
+}
+
+// Embed generates embeddings (DeepSeek has no embeddings endpoint)
+func (c *DeepSeekClient) Embed(ctx context.Context, model string, texts []string, dimensions *int32) ([][]float32, int64, error) {
+	return nil, 0, fmt.Errorf("DeepSeek does not support embeddings")
+}
+
+// CountTokens counts tokens in a request
+func (c *DeepSeekClient) CountTokens(ctx context.Context, req *domain.ChatRequest) (int32, error) {
+	var total int32
+	for _, msg := range req.Messages {
+		for _, block := range msg.Content {
+			if block.Type == "text" {
+				total += int32(len(block.Text) / 4)
+			}
+		}
+	}
+	return total, nil
+}
+
+// ListModels lists the DeepSeek models with their pricing
+func (c *DeepSeekClient) ListModels(ctx context.Context) ([]domain.ModelInfo, error) {
+	models := make([]domain.ModelInfo, len(deepseekModels))
+	for i, m := range deepseekModels {
+		m.Provider = domain.ProviderDeepSeek
+		m.Enabled = true
+		models[i] = m
+	}
+	return models, nil
+}
+
+// Helper methods
+
+// newRequest builds an authenticated request against the DeepSeek API
+func (c *DeepSeekClient) newRequest(ctx context.Context, method, path string, body map[string]any) (*http.Request, error) {
+	var reader io.Reader
+	if body != nil {
+		jsonBody, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = strings.NewReader(string(jsonBody))
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	return httpReq, nil
+}
+
+func (c *DeepSeekClient) buildRequest(req *domain.ChatRequest) map[string]any {
+	body := map[string]any{
+		"model":    c.resolveModelID(req.Model),
+		"messages": c.buildMessages(req),
+	}
+
+	if req.Temperature != nil {
+		body["temperature"] = *req.Temperature
+	}
+	if req.TopP != nil {
+		body["top_p"] = *req.TopP
+	}
+	if req.MaxTokens != nil {
+		body["max_tokens"] = *req.MaxTokens
+	}
+	if len(req.Tools) > 0 {
+		body["tools"] = c.convertTools(req.Tools)
+		if tc := openAIToolChoice(req.ToolChoice); tc != nil {
+			body["tool_choice"] = tc
+		}
+	}
+	// DeepSeek has JSON mode but no json_schema; the gateway puts the schema
+	// in the prompt and validates the output
+	if req.ResponseFormat.Structured() {
+		body["response_format"] = map[string]any{"type": domain.ResponseFormatJSONObject}
+	}
+
+	return body
+}
+
+func (c *DeepSeekClient) buildMessages(req *domain.ChatRequest) []map[string]any {
+	messages := make([]map[string]any, 0)
+
+	if req.SystemPrompt != "" {
+		messages = append(messages, map[string]any{
+			"role":    "system",
+			"content": req.SystemPrompt,
+		})
+	}
+
+	for _, msg := range req.Messages {
+		m := map[string]any{"role": msg.Role}
+
+		var textContent strings.Builder
+		for _, block := range msg.Content {
+			if block.Type == "text" {
+				textContent.WriteString(block.Text)
+			}
+		}
+		m["content"] = textContent.String()
+
+		if len(msg.ToolCalls) > 0 {
+			toolCalls := make([]map[string]any, len(msg.ToolCalls))
+			for i, tc := range msg.ToolCalls {
+				args, _ := json.Marshal(tc.Function.Arguments)
+				toolCalls[i] = map[string]any{
+					"id":   tc.ID,
+					"type": "function",
+					"function": map[string]any{
+						"name":      tc.Function.Name,
+						"arguments": string(args),
+					},
+				}
+			}
+			m["tool_calls"] = toolCalls
+		}
+
+		if msg.ToolCallID != "" {
+			m["tool_call_id"] = msg.ToolCallID
+		}
+
+		messages = append(messages, m)
+	}
+
+	return messages
+}
+
+func (c *DeepSeekClient) convertTools(tools []domain.Tool) []map[string]any {
+	result := make([]map[string]any, len(tools))
+	for i, tool := range tools {
+		result[i] = map[string]any{
+			"type": "function",
+			"function": map[string]any{
+				"name":        tool.Function.Name,
+				"description": tool.Function.Description,
+				"parameters":  tool.Function.Parameters,
+			},
+		}
+	}
+	return result
+}
+
+// processSSEStream reads OpenAI-style SSE chunks. deepseek-reasoner streams its
+// chain of thought as reasoning_content; tool call fragments are passed on as
+// deltas and accumulated by index.
+func (c *DeepSeekClient) processSSEStream(body io.Reader, events chan<- domain.StreamEvent) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var usage deepseekUsage
+	var finishReason domain.FinishReason
+	var toolCalls toolCallStream
+
+	finish := func() {
+		toolCalls.flush(events)
+		events <- *usage.event()
+		if finishReason == "" {
+			finishReason = domain.FinishReasonStop
+		}
+		events <- domain.FinishEvent{Reason: finishReason}
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+
+		data := strings.TrimPrefix(line, "data: ")
+		if data == "[DONE]" {
+			finish()
+			return
+		}
+
+		var chunk struct {
+			Choices []struct {
+				Delta struct {
+					Content          string `json:"content"`
+					ReasoningContent string `json:"reasoning_content"`
+					ToolCalls        []struct {
+						Index    int    `json:"index"`
+						ID       string `json:"id"`
+						Function struct {
+							Name      string `json:"name"`
+							Arguments string `json:"arguments"`
+						} `json:"function"`
+					} `json:"tool_calls"`
+				} `json:"delta"`
+				FinishReason string `json:"finish_reason"`
+			} `json:"choices"`
+			Usage *deepseekUsage `json:"usage"`
+		}
+
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			continue
+		}
+
+		if chunk.Usage != nil {
+			usage = *chunk.Usage
+		}
+
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+
+		delta := chunk.Choices[0].Delta
+		if delta.ReasoningContent != "" {
+			events <- domain.ThinkingChunk{Content: delta.ReasoningContent}
+		}
+		if delta.Content != "" {
+			events <- domain.TextChunk{Content: delta.Content}
+		}
+
+		for _, tc := range delta.ToolCalls {
+			toolCalls.add(events, tc.Index, tc.ID, tc.Function.Name, tc.Function.Arguments)
+		}
+
+		// With include_usage, usage arrives in a final chunk after the finish reason
+		switch chunk.Choices[0].FinishReason {
+		case "":
+		case "tool_calls":
+			finishReason = domain.FinishReasonToolCalls
+		case "length":
+			finishReason = domain.FinishReasonLength
+		default:
+			finishReason = domain.FinishReasonStop
+		}
+	}
+
+	finish()
+}
+
+// deepseekUsage is DeepSeek's usage object. prompt_cache_hit_tokens and
+// prompt_cache_miss_tokens split prompt_tokens by whether the context cache
+// served them.
+type deepseekUsage struct {
+	PromptTokens          int32 `json:"prompt_tokens"`
+	CompletionTokens      int32 `json:"completion_tokens"`
+	TotalTokens           int32 `json:"total_tokens"`
+	PromptCacheHitTokens  int32 `json:"prompt_cache_hit_tokens"`
+	PromptCacheMissTokens int32 `json:"prompt_cache_miss_tokens"`
+}
+
+func (u deepseekUsage) event() *domain.UsageEvent {
+	total := u.TotalTokens
+	if total == 0 {
+		total = u.PromptTokens + u.CompletionTokens
+	}
+	return &domain.UsageEvent{
+		PromptTokens:       u.PromptTokens,
+		CompletionTokens:   u.CompletionTokens,
+		TotalTokens:        total,
+		CachedPromptTokens: u.PromptCacheHitTokens,
+	}
+}
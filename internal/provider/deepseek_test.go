@@ -0,0 +1,54 @@
+package provider
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"modelgate/internal/domain"
+)
+
+func TestDeepSeekStream(t *testing.T) {
+	stream := strings.Join([]string{
+		`data: {"choices":[{"delta":{"role":"assistant","content":null,"reasoning_content":"The user wants "}}]}`,
+		`data: {"choices":[{"delta":{"content":null,"reasoning_content":"a greeting."}}]}`,
+		`data: {"choices":[{"delta":{"content":"Hello!"}}]}`,
+		`data: {"choices":[{"delta":{"content":""},"finish_reason":"stop"}],"usage":{"prompt_tokens":1200,"completion_tokens":40,"total_tokens":1240,"prompt_cache_hit_tokens":1024,"prompt_cache_miss_tokens":176}}`,
+		`data: [DONE]`,
+	}, "\n\n") + "\n\n"
+	events := collect(func(events chan<- domain.StreamEvent) {
+		(&DeepSeekClient{}).processSSEStream(strings.NewReader(stream), events)
+	})
+
+	want := []domain.StreamEvent{
+		domain.ThinkingChunk{Content: "The user wants "},
+		domain.ThinkingChunk{Content: "a greeting."},
+		domain.TextChunk{Content: "Hello!"},
+		domain.UsageEvent{PromptTokens: 1200, CompletionTokens: 40, TotalTokens: 1240, CachedPromptTokens: 1024},
+		domain.FinishEvent{Reason: domain.FinishReasonStop},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("expected %d events, got %+v", len(want), events)
+	}
+	for i, w := range want {
+		if events[i] != w {
+			t.Errorf("event %d: expected %+v, got %+v", i, w, events[i])
+		}
+	}
+
+	// Cache hits are billed at the cached input price
+	var chat domain.ModelInfo
+	for _, m := range deepseekModels {
+		if m.ID == "deepseek-chat" {
+			chat = m
+		}
+	}
+	price := &domain.ModelPrice{InputCostPer1M: chat.InputCostPer1M, OutputCostPer1M: chat.OutputCostPer1M, CachedInputCostPer1M: chat.CachedInputCostPer1M}
+	got := price.CostWithCache(1_000_000, 600_000, 1_000_000)
+	if want := 0.4*0.27 + 0.6*0.07 + 1.10; math.Abs(got-want) > 1e-9 {
+		t.Fatalf("expected cost %.4f, got %.4f", want, got)
+	}
+	if savings := price.CacheSavings(1_000_000, 600_000, 1_000_000); math.Abs(savings-0.6*0.20) > 1e-9 {
+		t.Fatalf("expected savings of %.4f, got %.4f", 0.6*0.20, savings)
+	}
+}
@@ -0,0 +1,164 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"modelgate/internal/domain"
+)
+
+// maxErrorMessage caps how much of an upstream error body is kept as the message
+const maxErrorMessage = 1000
+
+// errorHints maps phrases providers use in error messages to normalized codes.
+// They are checked before the HTTP status because several providers report
+// context length and content filter failures as a plain 400.
+var errorHints = []struct {
+	code    string
+	phrases []string
+}{
+	{domain.ErrorCodeContextLength, []string{"context_length_exceeded", "context length", "context window", "maximum context", "too many tokens", "prompt is too long", "input is too long", "exceeds the maximum number of tokens"}},
+	{domain.ErrorCodeContentFilter, []string{"content_filter", "content filter", "content_policy", "content management policy", "responsibleaipolicyviolation"}},
+	{domain.ErrorCodeInsufficientQuota, []string{"insufficient_quota", "exceeded your current quota", "billing", "credit balance"}},
+	{domain.ErrorCodeModelNotFound, []string{"model_not_found", "model not found", "does not exist", "unknown model", "no such model"}},
+	{domain.ErrorCodeInvalidAPIKey, []string{"invalid_api_key", "invalid api key", "api key not valid", "incorrect api key", "authentication_error", "unauthorized", "unrecognizedclientexception", "invalid x-api-key"}},
+	{domain.ErrorCodeRateLimit, []string{"rate_limit", "rate limit", "too many requests", "throttlingexception", "resource_exhausted"}},
+	{domain.ErrorCodeOverloaded, []string{"overloaded", "unavailable", "capacity", "circuit breaker open"}},
+	{domain.ErrorCodeTimeout, []string{"timeout", "timed out", "deadline exceeded"}},
+}
+
+// NewHTTPError normalizes a non-2xx provider response into a ProviderError
+func NewHTTPError(p domain.Provider, status int, body []byte) *domain.ProviderError {
+	message, upstreamCode := parseErrorBody(body)
+	if message == "" {
+		message = http.StatusText(status)
+	}
+	code := codeFromText(upstreamCode + " " + message)
+	if code == "" {
+		code = codeFromStatus(status)
+	}
+	pe := domain.NewProviderError(p, code, truncateStr(message, maxErrorMessage))
+	pe.UpstreamStatus = status
+	return pe
+}
+
+// NormalizeError converts any error returned by a provider call into a
+// ProviderError. Errors that already are one are returned as is.
+func NormalizeError(p domain.Provider, err error) *domain.ProviderError {
+	if err == nil {
+		return nil
+	}
+	var pe *domain.ProviderError
+	if errors.As(err, &pe) {
+		return pe
+	}
+
+	var code string
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.Canceled):
+		code = domain.ErrorCodeCancelled
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		code = domain.ErrorCodeTimeout
+	default:
+		code = codeFromText(err.Error())
+		if code == "" {
+			code = codeFromEmbeddedStatus(err.Error())
+		}
+	}
+	pe = domain.NewProviderError(p, code, truncateStr(err.Error(), maxErrorMessage))
+	pe.Err = err
+	return pe
+}
+
+// parseErrorBody extracts the message and provider error code/type from the
+// error body shapes providers use:
+//
+//	{"error": {"message": "...", "type": "...", "code": "..."}}  OpenAI, Anthropic, Gemini
+//	{"error": "..."}                                             Ollama
+//	{"message": "...", "__type": "..."}                          Bedrock, Cohere, Mistral
+func parseErrorBody(body []byte) (message, code string) {
+	var parsed struct {
+		Error   json.RawMessage `json:"error"`
+		Message string          `json:"message"`
+		Type    string          `json:"type"`
+		AWSType string          `json:"__type"`
+	}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return strings.TrimSpace(string(body)), ""
+	}
+
+	if len(parsed.Error) > 0 {
+		var nested struct {
+			Message string          `json:"message"`
+			Type    string          `json:"type"`
+			Code    json.RawMessage `json:"code"`
+			Status  string          `json:"status"`
+		}
+		if json.Unmarshal(parsed.Error, &nested) == nil {
+			// code is a string for OpenAI but a number for Gemini
+			var codeStr string
+			if json.Unmarshal(nested.Code, &codeStr) != nil {
+				codeStr = ""
+			}
+			return nested.Message, strings.Join([]string{nested.Type, codeStr, nested.Status}, " ")
+		}
+		var s string
+		if json.Unmarshal(parsed.Error, &s) == nil {
+			return s, ""
+		}
+	}
+	return parsed.Message, parsed.Type + " " + parsed.AWSType
+}
+
+func codeFromText(text string) string {
+	text = strings.ToLower(text)
+	for _, h := range errorHints {
+		for _, phrase := range h.phrases {
+			if strings.Contains(text, phrase) {
+				return h.code
+			}
+		}
+	}
+	return ""
+}
+
+func codeFromStatus(status int) string {
+	switch {
+	case status == http.StatusUnauthorized, status == http.StatusForbidden:
+		return domain.ErrorCodeInvalidAPIKey
+	case status == http.StatusNotFound:
+		return domain.ErrorCodeModelNotFound
+	case status == http.StatusRequestTimeout, status == http.StatusGatewayTimeout:
+		return domain.ErrorCodeTimeout
+	case status == http.StatusRequestEntityTooLarge:
+		return domain.ErrorCodeContextLength
+	case status == http.StatusTooManyRequests:
+		return domain.ErrorCodeRateLimit
+	case status == http.StatusServiceUnavailable, status == 529: // Anthropic's "overloaded"
+		return domain.ErrorCodeOverloaded
+	case status >= 400 && status < 500:
+		return domain.ErrorCodeInvalidRequest
+	default:
+		return domain.ErrorCodeProviderError
+	}
+}
+
+// codeFromEmbeddedStatus classifies errors from SDKs that only put the HTTP
+// status in the message, such as "StatusCode: 429"
+func codeFromEmbeddedStatus(msg string) string {
+	for _, field := range strings.FieldsFunc(msg, func(r rune) bool { return r < '0' || r > '9' }) {
+		if len(field) != 3 {
+			continue
+		}
+		if status, _ := strconv.Atoi(field); status >= 400 && status < 600 {
+			return codeFromStatus(status)
+		}
+	}
+	return domain.ErrorCodeProviderError
+}
@@ -0,0 +1,91 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"modelgate/internal/domain"
+)
+
+func TestNewHTTPError(t *testing.T) {
+	tests := []struct {
+		name       string
+		provider   domain.Provider
+		status     int
+		body       string
+		code       string
+		wantStatus int
+	}{
+		{"openai context length", domain.ProviderOpenAI, 400,
+			`{"error":{"message":"This model's maximum context length is 8192 tokens","type":"invalid_request_error","code":"context_length_exceeded"}}`,
+			domain.ErrorCodeContextLength, http.StatusBadRequest},
+		{"openai quota", domain.ProviderOpenAI, 429,
+			`{"error":{"message":"You exceeded your current quota","type":"insufficient_quota","code":"insufficient_quota"}}`,
+			domain.ErrorCodeInsufficientQuota, http.StatusTooManyRequests},
+		{"anthropic rate limit", domain.ProviderAnthropic, 429,
+			`{"type":"error","error":{"type":"rate_limit_error","message":"Number of requests has exceeded your rate limit"}}`,
+			domain.ErrorCodeRateLimit, http.StatusTooManyRequests},
+		{"anthropic overloaded", domain.ProviderAnthropic, 529,
+			`{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`,
+			domain.ErrorCodeOverloaded, http.StatusServiceUnavailable},
+		{"gemini bad key", domain.ProviderGemini, 400,
+			`{"error":{"code":400,"message":"API key not valid. Please pass a valid API key.","status":"INVALID_ARGUMENT"}}`,
+			domain.ErrorCodeInvalidAPIKey, http.StatusBadGateway},
+		{"gemini bad request", domain.ProviderGemini, 400,
+			`{"error":{"code":400,"message":"Invalid JSON payload received.","status":"INVALID_ARGUMENT"}}`,
+			domain.ErrorCodeInvalidRequest, http.StatusBadRequest},
+		{"azure content filter", domain.ProviderAzureOpenAI, 400,
+			`{"error":{"message":"The response was filtered","code":"content_filter"}}`,
+			domain.ErrorCodeContentFilter, http.StatusBadRequest},
+		{"ollama model not found", domain.ProviderOllama, 404,
+			`{"error":"model \"llama9\" not found, try pulling it first"}`,
+			domain.ErrorCodeModelNotFound, http.StatusNotFound},
+		{"bedrock throttling", domain.ProviderBedrock, 429,
+			`{"message":"Too many requests, please wait before trying again."}`,
+			domain.ErrorCodeRateLimit, http.StatusTooManyRequests},
+		{"invalid key by status", domain.ProviderGroq, 401, `not json`,
+			domain.ErrorCodeInvalidAPIKey, http.StatusBadGateway},
+		{"server error", domain.ProviderMistral, 500, ``,
+			domain.ErrorCodeProviderError, http.StatusBadGateway},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewHTTPError(tt.provider, tt.status, []byte(tt.body))
+			if err.Code != tt.code || err.Status != tt.wantStatus {
+				t.Fatalf("got code %q status %d, want %q %d (%v)", err.Code, err.Status, tt.code, tt.wantStatus, err)
+			}
+			if err.UpstreamStatus != tt.status || err.Message == "" {
+				t.Fatalf("unexpected error %+v", err)
+			}
+		})
+	}
+}
+
+func TestNormalizeError(t *testing.T) {
+	upstream := NewHTTPError(domain.ProviderOpenAI, 429, []byte(`{"error":{"message":"slow down","type":"requests","code":"rate_limit_exceeded"}}`))
+	if got := NormalizeError(domain.ProviderOpenAI, fmt.Errorf("max retries exceeded: %w", upstream)); got != upstream {
+		t.Fatalf("expected the wrapped provider error, got %v", got)
+	}
+	// Retry and key health detection still match on the message
+	if ClassifyError(upstream) != ErrorTypeRateLimit {
+		t.Fatalf("expected rate limit classification for %q", upstream.Error())
+	}
+
+	tests := []struct {
+		err  error
+		code string
+	}{
+		{context.DeadlineExceeded, domain.ErrorCodeTimeout},
+		{fmt.Errorf("send: %w", context.Canceled), domain.ErrorCodeCancelled},
+		{fmt.Errorf("circuit breaker open for provider openai"), domain.ErrorCodeOverloaded},
+		{fmt.Errorf("operation error: StatusCode: 403, AccessDenied"), domain.ErrorCodeInvalidAPIKey},
+		{fmt.Errorf("connection reset by peer"), domain.ErrorCodeProviderError},
+	}
+	for _, tt := range tests {
+		if got := NormalizeError(domain.ProviderBedrock, tt.err); got.Code != tt.code {
+			t.Errorf("NormalizeError(%q) = %q, want %q", tt.err, got.Code, tt.code)
+		}
+	}
+}
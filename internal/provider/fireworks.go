@@ -0,0 +1,619 @@
+// Package provider implements LLM provider clients.
+package provider
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"modelgate/internal/domain"
+)
+
+const fireworksAPIURL = "https://api.fireworks.ai/inference/v1"
+
+// fireworksModelPrefix is the account path of Fireworks' serverless models
+const fireworksModelPrefix = "accounts/fireworks/models/"
+
+// FireworksConfig contains Fireworks AI-specific settings
+type FireworksConfig struct {
+	APIKey             string
+	BaseURL            string // Optional override of the API URL
+	ConnectionSettings domain.ConnectionSettings
+}
+
+// FireworksClient implements the LLMClient interface for Fireworks AI. The
+// API is OpenAI-compatible; models are addressed as "fireworks/<model>",
+// where <model> is a serverless model's short name or a full
+// "accounts/<account>/models/<model>" path such as a fine-tuned model.
+// Besides json_schema, Fireworks constrains output to a GBNF grammar given
+// as a grammar response_format.
+type FireworksClient struct {
+	apiKey     string
+	baseURL    string
+	httpClient *http.Client
+	modelCache map[string]string // Cache of model aliases to native model IDs
+}
+
+// fireworksModels is Fireworks' published serverless pricing (USD per 1M
+// tokens), keyed by short model name. The model list comes from the API;
+// models without an entry here are priced from the pricing catalog.
+var fireworksModels = map[string]domain.ModelInfo{
+	"llama-v3p3-70b-instruct": {Name: "Llama 3.3 70B Instruct", SupportsTools: true, ContextLimit: 131072,
+		InputCostPer1M: 0.90, OutputCostPer1M: 0.90},
+	"llama-v3p1-405b-instruct": {Name: "Llama 3.1 405B Instruct", SupportsTools: true, ContextLimit: 131072,
+		InputCostPer1M: 3.00, OutputCostPer1M: 3.00},
+	"llama-v3p1-8b-instruct": {Name: "Llama 3.1 8B Instruct", ContextLimit: 131072,
+		InputCostPer1M: 0.20, OutputCostPer1M: 0.20},
+	"llama4-maverick-instruct-basic": {Name: "Llama 4 Maverick Instruct", SupportsTools: true, SupportsVision: true, ContextLimit: 1048576,
+		InputCostPer1M: 0.22, OutputCostPer1M: 0.88},
+	"llama4-scout-instruct-basic": {Name: "Llama 4 Scout Instruct", SupportsTools: true, SupportsVision: true, ContextLimit: 1048576,
+		InputCostPer1M: 0.15, OutputCostPer1M: 0.60},
+	"deepseek-v3": {Name: "DeepSeek V3", SupportsTools: true, ContextLimit: 131072,
+		InputCostPer1M: 0.90, OutputCostPer1M: 0.90},
+	"deepseek-r1": {Name: "DeepSeek R1", SupportsReasoning: true, ContextLimit: 163840,
+		InputCostPer1M: 3.00, OutputCostPer1M: 8.00},
+	"qwen3-235b-a22b": {Name: "Qwen3 235B A22B", SupportsTools: true, SupportsReasoning: true, ContextLimit: 131072,
+		InputCostPer1M: 0.22, OutputCostPer1M: 0.88},
+	"qwen2p5-72b-instruct": {Name: "Qwen 2.5 72B Instruct", SupportsTools: true, ContextLimit: 32768,
+		InputCostPer1M: 0.90, OutputCostPer1M: 0.90},
+	"mixtral-8x22b-instruct": {Name: "Mixtral 8x22B Instruct", SupportsTools: true, ContextLimit: 65536,
+		InputCostPer1M: 1.20, OutputCostPer1M: 1.20},
+	"nomic-embed-text-v1.5": {Name: "Nomic Embed Text v1.5", ContextLimit: 8192,
+		InputCostPer1M: 0.008},
+}
+
+// NewFireworksClient creates a new Fireworks AI client
+func NewFireworksClient(cfg FireworksConfig) (*FireworksClient, error) {
+	if cfg.APIKey == "" {
+		return nil, fmt.Errorf("Fireworks API key is required")
+	}
+
+	connSettings := cfg.ConnectionSettings
+	if connSettings.MaxConnections == 0 {
+		connSettings = domain.DefaultConnectionSettings()
+	}
+
+	baseURL := strings.TrimSuffix(cfg.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = fireworksAPIURL
+	}
+
+	return &FireworksClient{
+		apiKey:     cfg.APIKey,
+		baseURL:    baseURL,
+		httpClient: BuildHTTPClient(connSettings),
+		modelCache: make(map[string]string),
+	}, nil
+}
+
+// SetModelCache sets the model cache (implements ModelCacheable)
+func (c *FireworksClient) SetModelCache(cache map[string]string) {
+	c.modelCache = cache
+}
+
+// GetModelCache returns the model cache (implements ModelCacheable)
+func (c *FireworksClient) GetModelCache() map[string]string {
+	return c.modelCache
+}
+
+// resolveModelID resolves a model ID to its Fireworks path, using the cache
+// if available. Short names are serverless models.
+func (c *FireworksClient) resolveModelID(model string) string {
+	if c.modelCache != nil {
+		if nativeID, ok := c.modelCache[model]; ok {
+			return nativeID
+		}
+	}
+	return fireworksNativeModelID(strings.TrimPrefix(model, "fireworks/"))
+}
+
+// fireworksNativeModelID expands a short model name to the serverless model path
+func fireworksNativeModelID(model string) string {
+	if strings.HasPrefix(model, "accounts/") {
+		return model
+	}
+	return fireworksModelPrefix + model
+}
+
+// Provider returns the provider type
+func (c *FireworksClient) Provider() domain.Provider {
+	return domain.ProviderFireworks
+}
+
+// SupportsModel checks if a model is supported
+func (c *FireworksClient) SupportsModel(model string) bool {
+	return strings.HasPrefix(c.resolveModelID(model), "accounts/")
+}
+
+// ChatStream performs streaming chat completion
+func (c *FireworksClient) ChatStream(ctx context.Context, req *domain.ChatRequest) (<-chan domain.StreamEvent, error) {
+	events := make(chan domain.StreamEvent, 100)
+
+	go func() {
+		defer close(events)
+
+		body := c.buildRequest(req)
+		body["stream"] = true
+		body["stream_options"] = map[string]any{"include_usage": true}
+
+		httpReq, err := c.newRequest(ctx, "POST", "/chat/completions", body)
+		if err != nil {
+			events <- domain.FinishEvent{Reason: domain.FinishReasonError}
+			return
+		}
+
+		resp, err := c.httpClient.Do(httpReq)
+		if err != nil {
+			events <- domain.FinishEvent{Reason: domain.FinishReasonError}
+			return
+		}
+		defer resp.Body.Close()
+		observeQuota(ctx, resp.Header)
+
+		if resp.StatusCode != http.StatusOK {
+			bodyBytes, _ := io.ReadAll(resp.Body)
+			events <- domain.ErrorEvent{Err: NewHTTPError(c.Provider(), resp.StatusCode, bodyBytes)}
+			events <- domain.FinishEvent{Reason: domain.FinishReasonError}
+			return
+		}
+
+		c.processSSEStream(resp.Body, events)
+	}()
+
+	return events, nil
+}
+
+// ChatComplete performs non-streaming chat completion
+func (c *FireworksClient) ChatComplete(ctx context.Context, req *domain.ChatRequest) (*domain.ChatResponse, error) {
+	httpReq, err := c.newRequest(ctx, "POST", "/chat/completions", c.buildRequest(req))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	observeQuota(ctx, resp.Header)
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, NewHTTPError(c.Provider(), resp.StatusCode, bodyBytes)
+	}
+
+	var result struct {
+		Choices []struct {
+			Message struct {
+				Content          string `json:"content"`
+				ReasoningContent string `json:"reasoning_content"`
+				ToolCalls        []struct {
+					ID       string `json:"id"`
+					Type     string `json:"type"`
+					Function struct {
+						Name      string `json:"name"`
+						Arguments string `json:"arguments"`
+					} `json:"function"`
+				} `json:"tool_calls"`
+			} `json:"message"`
+			FinishReason string `json:"finish_reason"`
+		} `json:"choices"`
+		Usage fireworksUsage `json:"usage"`
+		Model string         `json:"model"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	response := &domain.ChatResponse{
+		Model: result.Model,
+		Usage: result.Usage.event(),
+	}
+
+	if len(result.Choices) > 0 {
+		choice := result.Choices[0]
+		response.Content = choice.Message.Content
+		response.Thinking = choice.Message.ReasoningContent
+		response.FinishReason = domain.FinishReason(choice.FinishReason)
+
+		for _, tc := range choice.Message.ToolCalls {
+			var args map[string]any
+			json.Unmarshal([]byte(tc.Function.Arguments), &args)
+			response.ToolCalls = append(response.ToolCalls, domain.ToolCall{
+				ID:   tc.ID,
+				Type: tc.Type,
+				Function: domain.FunctionCall{
+					Name:      tc.Function.Name,
+					Arguments: args,
+				},
+			})
+		}
+	}
+
+	return response, nil
+}
+
+// Embed generates embeddings
+func (c *FireworksClient) Embed(ctx context.Context, model string, texts []string, dimensions *int32) ([][]float32, int64, error) {
+	if model == "" {
+		model = "nomic-ai/nomic-embed-text-v1.5"
+	}
+
+	// Embedding models are also addressed by their Hugging Face name
+	model = strings.TrimPrefix(model, "fireworks/")
+	if !strings.Contains(model, "/") {
+		model = fireworksNativeModelID(model)
+	}
+
+	body := map[string]any{
+		"model": model,
+		"input": texts,
+	}
+	if dimensions != nil {
+		body["dimensions"] = *dimensions
+	}
+
+	httpReq, err := c.newRequest(ctx, "POST", "/embeddings", body)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	observeQuota(ctx, resp.Header)
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, 0, NewHTTPError(c.Provider(), resp.StatusCode, bodyBytes)
+	}
+
+	var result struct {
+		Data []struct {
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+		Usage struct {
+			TotalTokens int64 `json:"total_tokens"`
+		} `json:"usage"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, 0, err
+	}
+
+	embeddings := make([][]float32, len(result.Data))
+	for i, d := range result.Data {
+		embeddings[i] = d.Embedding
+	}
+
+	return embeddings, result.Usage.TotalTokens, nil
+}
+
+// CountTokens counts tokens in a request
+func (c *FireworksClient) CountTokens(ctx context.Context, req *domain.ChatRequest) (int32, error) {
+	var total int32
+	for _, msg := range req.Messages {
+		for _, block := range msg.Content {
+			if block.Type == "text" {
+				total += int32(len(block.Text) / 4)
+			}
+		}
+	}
+	return total, nil
+}
+
+// ListModels lists the models the account can use, with Fireworks' published
+// pricing for the serverless models
+func (c *FireworksClient) ListModels(ctx context.Context) ([]domain.ModelInfo, error) {
+	httpReq, err := c.newRequest(ctx, "GET", "/models", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	observeQuota(ctx, resp.Header)
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, NewHTTPError(c.Provider(), resp.StatusCode, bodyBytes)
+	}
+
+	var result struct {
+		Data []fireworksModel `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	models := make([]domain.ModelInfo, 0, len(result.Data))
+	for _, m := range result.Data {
+		models = append(models, m.info())
+	}
+
+	sort.Slice(models, func(i, j int) bool { return models[i].ID < models[j].ID })
+	return models, nil
+}
+
+// fireworksModel is an entry of Fireworks' model list
+type fireworksModel struct {
+	ID                 string `json:"id"`
+	ContextLength      uint32 `json:"context_length"`
+	SupportsChat       bool   `json:"supports_chat"`
+	SupportsTools      bool   `json:"supports_tools"`
+	SupportsImageInput bool   `json:"supports_image_input"`
+}
+
+// info converts a listed model, merging in the published price table.
+// Serverless models are listed by short name and the account's own models
+// by their full path.
+func (m fireworksModel) info() domain.ModelInfo {
+	short := strings.TrimPrefix(m.ID, fireworksModelPrefix)
+	info := fireworksModels[short]
+	info.ID = "fireworks/" + short
+	info.Provider = domain.ProviderFireworks
+	info.NativeModelID = m.ID
+	info.Enabled = true
+	if info.Name == "" {
+		info.Name = short
+	}
+	info.SupportsTools = info.SupportsTools || m.SupportsTools
+	info.SupportsVision = info.SupportsVision || m.SupportsImageInput
+	if m.ContextLength > 0 {
+		info.ContextLimit = m.ContextLength
+	}
+	return info
+}
+
+// Helper methods
+
+// newRequest builds an authenticated request against the Fireworks API
+func (c *FireworksClient) newRequest(ctx context.Context, method, path string, body map[string]any) (*http.Request, error) {
+	var reader io.Reader
+	if body != nil {
+		jsonBody, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = strings.NewReader(string(jsonBody))
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	return httpReq, nil
+}
+
+func (c *FireworksClient) buildRequest(req *domain.ChatRequest) map[string]any {
+	body := map[string]any{
+		"model":    c.resolveModelID(req.Model),
+		"messages": c.buildMessages(req),
+	}
+
+	if req.Temperature != nil {
+		body["temperature"] = *req.Temperature
+	}
+	if req.TopP != nil {
+		body["top_p"] = *req.TopP
+	}
+	if req.MaxTokens != nil {
+		body["max_tokens"] = *req.MaxTokens
+	}
+	if len(req.Tools) > 0 {
+		body["tools"] = c.convertTools(req.Tools)
+		if tc := openAIToolChoice(req.ToolChoice); tc != nil {
+			body["tool_choice"] = tc
+		}
+	}
+	if f := req.ResponseFormat; f != nil && f.Type == domain.ResponseFormatGrammar {
+		body["response_format"] = map[string]any{
+			"type":    domain.ResponseFormatGrammar,
+			"grammar": f.Grammar,
+		}
+	} else if rf := openAIResponseFormat(f); rf != nil {
+		body["response_format"] = rf
+	}
+
+	return body
+}
+
+func (c *FireworksClient) buildMessages(req *domain.ChatRequest) []map[string]any {
+	messages := make([]map[string]any, 0)
+
+	if req.SystemPrompt != "" {
+		messages = append(messages, map[string]any{
+			"role":    "system",
+			"content": req.SystemPrompt,
+		})
+	}
+
+	for _, msg := range req.Messages {
+		m := map[string]any{"role": msg.Role}
+
+		// Text-only messages are sent as a string; images need content parts
+		var textContent strings.Builder
+		var parts []map[string]any
+		for _, block := range msg.Content {
+			switch block.Type {
+			case "text":
+				textContent.WriteString(block.Text)
+				parts = append(parts, map[string]any{"type": "text", "text": block.Text})
+			case "image":
+				parts = append(parts, map[string]any{
+					"type":      "image_url",
+					"image_url": map[string]string{"url": block.ImageURL},
+				})
+			}
+		}
+		if messageHasImages(msg) {
+			m["content"] = parts
+		} else {
+			m["content"] = textContent.String()
+		}
+
+		if len(msg.ToolCalls) > 0 {
+			toolCalls := make([]map[string]any, len(msg.ToolCalls))
+			for i, tc := range msg.ToolCalls {
+				args, _ := json.Marshal(tc.Function.Arguments)
+				toolCalls[i] = map[string]any{
+					"id":   tc.ID,
+					"type": "function",
+					"function": map[string]any{
+						"name":      tc.Function.Name,
+						"arguments": string(args),
+					},
+				}
+			}
+			m["tool_calls"] = toolCalls
+		}
+
+		if msg.ToolCallID != "" {
+			m["tool_call_id"] = msg.ToolCallID
+		}
+
+		messages = append(messages, m)
+	}
+
+	return messages
+}
+
+func (c *FireworksClient) convertTools(tools []domain.Tool) []map[string]any {
+	result := make([]map[string]any, len(tools))
+	for i, tool := range tools {
+		result[i] = map[string]any{
+			"type": "function",
+			"function": map[string]any{
+				"name":        tool.Function.Name,
+				"description": tool.Function.Description,
+				"parameters":  tool.Function.Parameters,
+			},
+		}
+	}
+	return result
+}
+
+// processSSEStream reads OpenAI-style SSE chunks. Reasoning models stream
+// their chain of thought as reasoning_content; tool call fragments are passed
+// on as deltas and accumulated by index.
+func (c *FireworksClient) processSSEStream(body io.Reader, events chan<- domain.StreamEvent) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var usage fireworksUsage
+	var finishReason domain.FinishReason
+	var toolCalls toolCallStream
+
+	finish := func() {
+		toolCalls.flush(events)
+		events <- *usage.event()
+		if finishReason == "" {
+			finishReason = domain.FinishReasonStop
+		}
+		events <- domain.FinishEvent{Reason: finishReason}
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+
+		data := strings.TrimPrefix(line, "data: ")
+		if data == "[DONE]" {
+			finish()
+			return
+		}
+
+		var chunk struct {
+			Choices []struct {
+				Delta struct {
+					Content          string `json:"content"`
+					ReasoningContent string `json:"reasoning_content"`
+					ToolCalls        []struct {
+						Index    int    `json:"index"`
+						ID       string `json:"id"`
+						Function struct {
+							Name      string `json:"name"`
+							Arguments string `json:"arguments"`
+						} `json:"function"`
+					} `json:"tool_calls"`
+				} `json:"delta"`
+				FinishReason string `json:"finish_reason"`
+			} `json:"choices"`
+			Usage *fireworksUsage `json:"usage"`
+		}
+
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			continue
+		}
+
+		if chunk.Usage != nil {
+			usage = *chunk.Usage
+		}
+
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+
+		delta := chunk.Choices[0].Delta
+		if delta.ReasoningContent != "" {
+			events <- domain.ThinkingChunk{Content: delta.ReasoningContent}
+		}
+		if delta.Content != "" {
+			events <- domain.TextChunk{Content: delta.Content}
+		}
+
+		for _, tc := range delta.ToolCalls {
+			toolCalls.add(events, tc.Index, tc.ID, tc.Function.Name, tc.Function.Arguments)
+		}
+
+		switch chunk.Choices[0].FinishReason {
+		case "":
+		case "tool_calls":
+			finishReason = domain.FinishReasonToolCalls
+		case "length":
+			finishReason = domain.FinishReasonLength
+		default:
+			finishReason = domain.FinishReasonStop
+		}
+	}
+
+	finish()
+}
+
+// fireworksUsage is Fireworks' usage object. Fireworks caches prompts but
+// bills cached tokens at the full input price, so none are reported as cached.
+type fireworksUsage struct {
+	PromptTokens     int32 `json:"prompt_tokens"`
+	CompletionTokens int32 `json:"completion_tokens"`
+	TotalTokens      int32 `json:"total_tokens"`
+}
+
+func (u fireworksUsage) event() *domain.UsageEvent {
+	total := u.TotalTokens
+	if total == 0 {
+		total = u.PromptTokens + u.CompletionTokens
+	}
+	return &domain.UsageEvent{
+		PromptTokens:     u.PromptTokens,
+		CompletionTokens: u.CompletionTokens,
+		TotalTokens:      total,
+	}
+}
@@ -0,0 +1,79 @@
+package provider
+
+import (
+	"strings"
+	"testing"
+
+	"modelgate/internal/domain"
+)
+
+func TestFireworksStream(t *testing.T) {
+	stream := strings.Join([]string{
+		`data: {"choices":[{"delta":{"role":"assistant","reasoning_content":"Checking the weather."}}]}`,
+		`data: {"choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"get_weather","arguments":"{\"city\":"}}]}}]}`,
+		`data: {"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"Paris\"}"}}]},"finish_reason":"tool_calls"}]}`,
+		`data: {"choices":[],"usage":{"prompt_tokens":80,"completion_tokens":20,"total_tokens":100}}`,
+		`data: [DONE]`,
+	}, "\n\n") + "\n\n"
+	events := collect(func(events chan<- domain.StreamEvent) {
+		(&FireworksClient{}).processSSEStream(strings.NewReader(stream), events)
+	})
+
+	var thinking string
+	var call *domain.ToolCall
+	var usage *domain.UsageEvent
+	var finish domain.FinishReason
+	for _, event := range events {
+		switch e := event.(type) {
+		case domain.ThinkingChunk:
+			thinking += e.Content
+		case domain.ToolCallEvent:
+			call = &e.ToolCall
+		case domain.UsageEvent:
+			usage = &e
+		case domain.FinishEvent:
+			finish = e.Reason
+		}
+	}
+	if thinking != "Checking the weather." {
+		t.Errorf("thinking = %q", thinking)
+	}
+	if call == nil || call.ID != "call_1" || call.Function.Name != "get_weather" || call.Function.Arguments["city"] != "Paris" {
+		t.Errorf("unexpected tool call %+v", call)
+	}
+	if usage == nil || usage.TotalTokens != 100 || usage.CachedPromptTokens != 0 {
+		t.Errorf("unexpected usage %+v", usage)
+	}
+	if finish != domain.FinishReasonToolCalls {
+		t.Errorf("finish reason = %s", finish)
+	}
+}
+
+func TestFireworksRequest(t *testing.T) {
+	c := &FireworksClient{}
+	grammar := `root ::= "yes" | "no"`
+	body := c.buildRequest(&domain.ChatRequest{
+		Model:          "fireworks/llama-v3p3-70b-instruct",
+		ResponseFormat: &domain.ResponseFormat{Type: domain.ResponseFormatGrammar, Grammar: grammar},
+	})
+	if body["model"] != "accounts/fireworks/models/llama-v3p3-70b-instruct" {
+		t.Errorf("model = %v", body["model"])
+	}
+	rf, _ := body["response_format"].(map[string]any)
+	if rf["type"] != "grammar" || rf["grammar"] != grammar {
+		t.Errorf("response_format = %v", body["response_format"])
+	}
+
+	// Fine-tuned models keep their account path
+	body = c.buildRequest(&domain.ChatRequest{Model: "fireworks/accounts/acme/models/support-ft"})
+	if body["model"] != "accounts/acme/models/support-ft" || body["response_format"] != nil {
+		t.Errorf("unexpected request %v", body)
+	}
+
+	// Listed models are priced from the published table
+	info := fireworksModel{ID: "accounts/fireworks/models/deepseek-r1", ContextLength: 163840}.info()
+	if info.ID != "fireworks/deepseek-r1" || info.NativeModelID != "accounts/fireworks/models/deepseek-r1" ||
+		info.InputCostPer1M != 3.00 || info.OutputCostPer1M != 8.00 || !info.SupportsReasoning {
+		t.Errorf("unexpected model info %+v", info)
+	}
+}
@@ -4,6 +4,7 @@ package provider
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -116,15 +117,15 @@ func (c *GeminiClient) ChatStream(ctx context.Context, req *domain.ChatRequest)
 			return
 		}
 		defer resp.Body.Close()
+		observeQuota(ctx, resp.Header)
 
 		slog.Debug("[GEMINI] Response status", "status", resp.Status, "status_code", resp.StatusCode)
 
 		if resp.StatusCode != http.StatusOK {
 			bodyBytes, _ := io.ReadAll(resp.Body)
 			slog.Error("[GEMINI] API error", "status", resp.Status, "body", string(bodyBytes))
-			eventChan <- domain.PolicyViolationEvent{
-				Message: fmt.Sprintf("API error: %s - %s", resp.Status, string(bodyBytes)),
-			}
+			eventChan <- domain.ErrorEvent{Err: NewHTTPError(c.Provider(), resp.StatusCode, bodyBytes)}
+			eventChan <- domain.FinishEvent{Reason: domain.FinishReasonError}
 			return
 		}
 
@@ -208,10 +209,11 @@ func (c *GeminiClient) Embed(ctx context.Context, model string, texts []string,
 		return nil, 0, err
 	}
 	defer resp.Body.Close()
+	observeQuota(ctx, resp.Header)
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, 0, fmt.Errorf("API error: %s - %s", resp.Status, string(bodyBytes))
+		return nil, 0, NewHTTPError(c.Provider(), resp.StatusCode, bodyBytes)
 	}
 
 	var result struct {
@@ -257,6 +259,7 @@ func (c *GeminiClient) CountTokens(ctx context.Context, req *domain.ChatRequest)
 		return 0, err
 	}
 	defer resp.Body.Close()
+	observeQuota(ctx, resp.Header)
 
 	var result struct {
 		TotalTokens int32 `json:"totalTokens"`
@@ -282,6 +285,7 @@ func (c *GeminiClient) ListModels(ctx context.Context) ([]domain.ModelInfo, erro
 		return nil, err
 	}
 	defer resp.Body.Close()
+	observeQuota(ctx, resp.Header)
 
 	// Read response body for debugging
 	bodyBytes, err := io.ReadAll(resp.Body)
@@ -406,6 +410,22 @@ func (c *GeminiClient) buildRequest(req *domain.ChatRequest) map[string]any {
 						},
 					})
 				}
+			case "file":
+				if content.ProviderFileID != "" {
+					parts = append(parts, map[string]any{
+						"fileData": map[string]string{
+							"fileUri":  content.ProviderFileID,
+							"mimeType": content.MediaType,
+						},
+					})
+				} else if len(content.FileData) > 0 {
+					parts = append(parts, map[string]any{
+						"inlineData": map[string]string{
+							"mimeType": content.MediaType,
+							"data":     base64.StdEncoding.EncodeToString(content.FileData),
+						},
+					})
+				}
 			}
 		}
 
@@ -478,6 +498,9 @@ func (c *GeminiClient) buildRequest(req *domain.ChatRequest) map[string]any {
 	if req.Temperature != nil {
 		generationConfig["temperature"] = *req.Temperature
 	}
+	if req.TopP != nil {
+		generationConfig["topP"] = *req.TopP
+	}
 	if req.MaxTokens != nil {
 		generationConfig["maxOutputTokens"] = *req.MaxTokens
 	}
@@ -571,7 +594,7 @@ func (c *GeminiClient) parseChunk(data string, eventChan chan<- domain.StreamEve
 	// Check for errors in response
 	if chunk.Error.Message != "" {
 		slog.Error("[GEMINI] API error in chunk", "code", chunk.Error.Code, "message", chunk.Error.Message, "status", chunk.Error.Status)
-		eventChan <- domain.PolicyViolationEvent{Message: chunk.Error.Message}
+		eventChan <- domain.ErrorEvent{Err: NewHTTPError(c.Provider(), chunk.Error.Code, []byte(data))}
 		return
 	}
 
@@ -0,0 +1,99 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// UploadFile uploads a file with the Gemini Files API and returns its URI,
+// which chat requests reference as fileData (implements FileCapable). Gemini
+// deletes files after 48 hours.
+func (c *GeminiClient) UploadFile(ctx context.Context, filename, mimeType string, size int64, content io.Reader) (string, error) {
+	uploadBase := strings.Replace(c.baseURL, "/v1beta", "/upload/v1beta", 1)
+	meta, err := json.Marshal(map[string]any{"file": map[string]string{"display_name": filename}})
+	if err != nil {
+		return "", err
+	}
+
+	// Start a resumable upload, then send the content in one request
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/files?key=%s", uploadBase, c.apiKey), bytes.NewReader(meta))
+	if err != nil {
+		return "", err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("X-Goog-Upload-Protocol", "resumable")
+	httpReq.Header.Set("X-Goog-Upload-Command", "start")
+	httpReq.Header.Set("X-Goog-Upload-Header-Content-Length", strconv.FormatInt(size, 10))
+	httpReq.Header.Set("X-Goog-Upload-Header-Content-Type", mimeType)
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	uploadURL := resp.Header.Get("X-Goog-Upload-URL")
+	if resp.StatusCode != http.StatusOK || uploadURL == "" {
+		return "", fmt.Errorf("start file upload: %s", resp.Status)
+	}
+
+	httpReq, err = http.NewRequestWithContext(ctx, "POST", uploadURL, content)
+	if err != nil {
+		return "", err
+	}
+	httpReq.ContentLength = size
+	httpReq.Header.Set("X-Goog-Upload-Offset", "0")
+	httpReq.Header.Set("X-Goog-Upload-Command", "upload, finalize")
+
+	resp, err = c.httpClient.Do(httpReq)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("upload file: %s - %s", resp.Status, string(bodyBytes))
+	}
+
+	var result struct {
+		File struct {
+			URI string `json:"uri"`
+		} `json:"file"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	if result.File.URI == "" {
+		return "", fmt.Errorf("upload file: response has no file URI")
+	}
+	return result.File.URI, nil
+}
+
+// DeleteFile deletes a file uploaded with UploadFile (implements FileCapable)
+func (c *GeminiClient) DeleteFile(ctx context.Context, providerFileID string) error {
+	idx := strings.LastIndex(providerFileID, "files/")
+	if idx < 0 {
+		return fmt.Errorf("invalid file URI: %s", providerFileID)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, "DELETE", fmt.Sprintf("%s/%s?key=%s", c.baseURL, providerFileID[idx:], c.apiKey), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// Gemini may already have deleted an expired file
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("delete file: %s - %s", resp.Status, string(bodyBytes))
+	}
+	return nil
+}
@@ -105,11 +105,20 @@ func (c *GroqClient) ChatStream(ctx context.Context, req *domain.ChatRequest) (<
 		if req.Temperature != nil {
 			body["temperature"] = *req.Temperature
 		}
+		if req.TopP != nil {
+			body["top_p"] = *req.TopP
+		}
 		if req.MaxTokens != nil {
 			body["max_tokens"] = *req.MaxTokens
 		}
 		if len(req.Tools) > 0 {
 			body["tools"] = c.convertTools(req.Tools)
+			if tc := openAIToolChoice(req.ToolChoice); tc != nil {
+				body["tool_choice"] = tc
+			}
+		}
+		if rf := openAIResponseFormat(req.ResponseFormat); rf != nil {
+			body["response_format"] = rf
 		}
 
 		jsonBody, _ := json.Marshal(body)
@@ -128,10 +137,11 @@ func (c *GroqClient) ChatStream(ctx context.Context, req *domain.ChatRequest) (<
 			return
 		}
 		defer resp.Body.Close()
+		observeQuota(ctx, resp.Header)
 
 		if resp.StatusCode != http.StatusOK {
 			bodyBytes, _ := io.ReadAll(resp.Body)
-			events <- domain.TextChunk{Content: fmt.Sprintf("Groq error: %s", string(bodyBytes))}
+			events <- domain.ErrorEvent{Err: NewHTTPError(c.Provider(), resp.StatusCode, bodyBytes)}
 			events <- domain.FinishEvent{Reason: domain.FinishReasonError}
 			return
 		}
@@ -155,11 +165,20 @@ func (c *GroqClient) ChatComplete(ctx context.Context, req *domain.ChatRequest)
 	if req.Temperature != nil {
 		body["temperature"] = *req.Temperature
 	}
+	if req.TopP != nil {
+		body["top_p"] = *req.TopP
+	}
 	if req.MaxTokens != nil {
 		body["max_tokens"] = *req.MaxTokens
 	}
 	if len(req.Tools) > 0 {
 		body["tools"] = c.convertTools(req.Tools)
+		if tc := openAIToolChoice(req.ToolChoice); tc != nil {
+			body["tool_choice"] = tc
+		}
+	}
+	if rf := openAIResponseFormat(req.ResponseFormat); rf != nil {
+		body["response_format"] = rf
 	}
 
 	jsonBody, _ := json.Marshal(body)
@@ -176,10 +195,11 @@ func (c *GroqClient) ChatComplete(ctx context.Context, req *domain.ChatRequest)
 		return nil, err
 	}
 	defer resp.Body.Close()
+	observeQuota(ctx, resp.Header)
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("Groq API error: %s", string(bodyBytes))
+		return nil, NewHTTPError(c.Provider(), resp.StatusCode, bodyBytes)
 	}
 
 	var result struct {
@@ -399,6 +419,7 @@ func (c *GroqClient) convertTools(tools []domain.Tool) []map[string]any {
 func (c *GroqClient) processSSEStream(body io.Reader, events chan<- domain.StreamEvent) {
 	scanner := bufio.NewScanner(body)
 	var inputTokens, outputTokens int32
+	var toolCalls toolCallStream
 
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -408,6 +429,7 @@ func (c *GroqClient) processSSEStream(body io.Reader, events chan<- domain.Strea
 
 		data := strings.TrimPrefix(line, "data: ")
 		if data == "[DONE]" {
+			toolCalls.flush(events)
 			events <- domain.UsageEvent{
 				PromptTokens:     inputTokens,
 				CompletionTokens: outputTokens,
@@ -457,23 +479,11 @@ func (c *GroqClient) processSSEStream(body io.Reader, events chan<- domain.Strea
 			}
 
 			for _, tc := range delta.ToolCalls {
-				if tc.ID != "" {
-					var args map[string]any
-					json.Unmarshal([]byte(tc.Function.Arguments), &args)
-					events <- domain.ToolCallEvent{
-						ToolCall: domain.ToolCall{
-							ID:   tc.ID,
-							Type: "function",
-							Function: domain.FunctionCall{
-								Name:      tc.Function.Name,
-								Arguments: args,
-							},
-						},
-					}
-				}
+				toolCalls.add(events, tc.Index, tc.ID, tc.Function.Name, tc.Function.Arguments)
 			}
 
 			if chunk.Choices[0].FinishReason != "" {
+				toolCalls.flush(events)
 				if chunk.Choices[0].FinishReason == "tool_calls" {
 					events <- domain.FinishEvent{Reason: domain.FinishReasonToolCalls}
 				} else {
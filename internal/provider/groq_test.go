@@ -0,0 +1,11 @@
+package provider
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestGroqToolChoice(t *testing.T) {
+	recorder := &bodyRecorder{}
+	checkToolChoiceSent(t, recorder, &GroqClient{apiKey: "key", httpClient: &http.Client{Transport: recorder}})
+}
@@ -0,0 +1,579 @@
+// Package provider implements LLM provider clients.
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+const huggingFaceRouterURL = "https://router.huggingface.co/v1"
+
+// HuggingFaceConfig contains Hugging Face-specific settings
+type HuggingFaceConfig struct {
+	APIKey             string // Hugging Face access token
+	BaseURL            string // Optional override of the serverless Inference API URL
+	Endpoints          []domain.HuggingFaceEndpoint
+	ConnectionSettings domain.ConnectionSettings
+}
+
+// HuggingFaceClient implements the LLMClient interface for Hugging Face.
+// Models are addressed as "huggingface/<name>": a name matching a configured
+// Inference Endpoint goes to that endpoint's TGI server, anything else (a Hub
+// model ID such as "meta-llama/Llama-3.1-8B-Instruct") to the serverless
+// Inference API. Both speak the OpenAI-compatible Messages API.
+type HuggingFaceClient struct {
+	apiKey     string
+	baseURL    string
+	endpoints  map[string]domain.HuggingFaceEndpoint
+	httpClient *http.Client
+}
+
+// NewHuggingFaceClient creates a new Hugging Face client
+func NewHuggingFaceClient(cfg HuggingFaceConfig) (*HuggingFaceClient, error) {
+	if cfg.APIKey == "" {
+		return nil, fmt.Errorf("Hugging Face access token is required")
+	}
+
+	connSettings := cfg.ConnectionSettings
+	if connSettings.MaxConnections == 0 {
+		connSettings = domain.DefaultConnectionSettings()
+	}
+
+	baseURL := strings.TrimSuffix(cfg.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = huggingFaceRouterURL
+	}
+
+	endpoints := make(map[string]domain.HuggingFaceEndpoint, len(cfg.Endpoints))
+	for _, e := range cfg.Endpoints {
+		if e.Enabled {
+			endpoints[e.Name] = e
+		}
+	}
+
+	return &HuggingFaceClient{
+		apiKey:     cfg.APIKey,
+		baseURL:    baseURL,
+		endpoints:  endpoints,
+		httpClient: BuildHTTPClient(connSettings),
+	}, nil
+}
+
+// Provider returns the provider type
+func (c *HuggingFaceClient) Provider() domain.Provider {
+	return domain.ProviderHuggingFace
+}
+
+// modelID strips the "huggingface/" prefix. Hub model IDs contain a slash
+// themselves, so only that prefix is removed.
+func (c *HuggingFaceClient) modelID(model string) string {
+	return strings.TrimPrefix(model, string(domain.ProviderHuggingFace)+"/")
+}
+
+// SupportsModel checks if a model is a configured endpoint or a Hub model ID
+func (c *HuggingFaceClient) SupportsModel(model string) bool {
+	id := c.modelID(model)
+	_, ok := c.endpoints[id]
+	return ok || strings.Contains(id, "/")
+}
+
+// hfTarget is where a request goes: a dedicated endpoint, or the serverless
+// API when endpoint is nil
+type hfTarget struct {
+	url      string
+	model    string
+	endpoint *domain.HuggingFaceEndpoint
+}
+
+// target resolves the model of a request, rejecting requests using
+// capabilities an endpoint wasn't declared with
+func (c *HuggingFaceClient) target(req *domain.ChatRequest) (*hfTarget, error) {
+	id := c.modelID(req.Model)
+	e, ok := c.endpoints[id]
+	if !ok {
+		return &hfTarget{url: c.baseURL + "/chat/completions", model: id}, nil
+	}
+	if len(req.Tools) > 0 && !e.SupportsTools {
+		return nil, domain.NewProviderError(domain.ProviderHuggingFace, domain.ErrorCodeInvalidRequest,
+			fmt.Sprintf("endpoint %s does not support tools", e.Name))
+	}
+	if !e.SupportsVision && hasImages(req) {
+		return nil, domain.NewProviderError(domain.ProviderHuggingFace, domain.ErrorCodeInvalidRequest,
+			fmt.Sprintf("endpoint %s does not support images", e.Name))
+	}
+	return &hfTarget{url: strings.TrimSuffix(e.URL, "/") + "/v1/chat/completions", model: e.Upstream(), endpoint: &e}, nil
+}
+
+// cost prices the time a request held a dedicated endpoint at its hourly rate
+func (t *hfTarget) cost(elapsed time.Duration) float64 {
+	if t.endpoint == nil {
+		return 0
+	}
+	return t.endpoint.HourlyCostUSD * elapsed.Hours()
+}
+
+// ChatStream performs streaming chat completion. Endpoints declared without
+// streaming support are called without it and their response is replayed as
+// a stream.
+func (c *HuggingFaceClient) ChatStream(ctx context.Context, req *domain.ChatRequest) (<-chan domain.StreamEvent, error) {
+	t, err := c.target(req)
+	if err != nil {
+		return nil, err
+	}
+	events := make(chan domain.StreamEvent, 100)
+
+	if t.endpoint != nil && !t.endpoint.SupportsStreaming {
+		go func() {
+			defer close(events)
+			resp, err := c.complete(ctx, t, req)
+			if err != nil {
+				events <- domain.ErrorEvent{Err: NormalizeError(domain.ProviderHuggingFace, err)}
+				events <- domain.FinishEvent{Reason: domain.FinishReasonError}
+				return
+			}
+			if resp.Content != "" {
+				events <- domain.TextChunk{Content: resp.Content}
+			}
+			for _, tc := range resp.ToolCalls {
+				events <- domain.ToolCallEvent{ToolCall: tc}
+			}
+			events <- *resp.Usage
+			events <- domain.FinishEvent{Reason: resp.FinishReason}
+		}()
+		return events, nil
+	}
+
+	go func() {
+		defer close(events)
+
+		body := c.buildRequest(t, req)
+		body["stream"] = true
+		body["stream_options"] = map[string]any{"include_usage": true}
+
+		start := time.Now()
+		resp, err := c.do(ctx, t, body)
+		if err != nil {
+			events <- domain.ErrorEvent{Err: NormalizeError(domain.ProviderHuggingFace, err)}
+			events <- domain.FinishEvent{Reason: domain.FinishReasonError}
+			return
+		}
+		defer resp.Body.Close()
+
+		promptTokens, _ := c.CountTokens(ctx, req)
+		c.processSSEStream(resp.Body, events, promptTokens, func() float64 { return t.cost(time.Since(start)) })
+	}()
+
+	return events, nil
+}
+
+// ChatComplete performs non-streaming chat completion
+func (c *HuggingFaceClient) ChatComplete(ctx context.Context, req *domain.ChatRequest) (*domain.ChatResponse, error) {
+	t, err := c.target(req)
+	if err != nil {
+		return nil, err
+	}
+	return c.complete(ctx, t, req)
+}
+
+func (c *HuggingFaceClient) complete(ctx context.Context, t *hfTarget, req *domain.ChatRequest) (*domain.ChatResponse, error) {
+	start := time.Now()
+	resp, err := c.do(ctx, t, c.buildRequest(t, req))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Choices []struct {
+			Message struct {
+				Content   string `json:"content"`
+				ToolCalls []struct {
+					ID       string         `json:"id"`
+					Function hfFunctionCall `json:"function"`
+				} `json:"tool_calls"`
+			} `json:"message"`
+			FinishReason string `json:"finish_reason"`
+		} `json:"choices"`
+		Usage struct {
+			PromptTokens     int32 `json:"prompt_tokens"`
+			CompletionTokens int32 `json:"completion_tokens"`
+			TotalTokens      int32 `json:"total_tokens"`
+		} `json:"usage"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	response := &domain.ChatResponse{
+		Model:        req.Model,
+		FinishReason: domain.FinishReasonStop,
+		Usage: &domain.UsageEvent{
+			PromptTokens:     result.Usage.PromptTokens,
+			CompletionTokens: result.Usage.CompletionTokens,
+			TotalTokens:      result.Usage.TotalTokens,
+			CostUSD:          t.cost(time.Since(start)),
+		},
+	}
+	response.CostUSD = response.Usage.CostUSD
+
+	if len(result.Choices) > 0 {
+		choice := result.Choices[0]
+		response.Content = choice.Message.Content
+		response.FinishReason = hfFinishReason(choice.FinishReason)
+
+		for i, tc := range choice.Message.ToolCalls {
+			id := tc.ID
+			if id == "" {
+				id = fmt.Sprintf("call_%d", i)
+			}
+			response.ToolCalls = append(response.ToolCalls, domain.ToolCall{
+				ID:       id,
+				Type:     "function",
+				Function: domain.FunctionCall{Name: tc.Function.Name, Arguments: tc.Function.arguments()},
+			})
+		}
+	}
+
+	return response, nil
+}
+
+// hfFunctionCall is a tool call's function. Older TGI versions send the
+// arguments as a JSON object rather than a string.
+type hfFunctionCall struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+func (f hfFunctionCall) arguments() map[string]any {
+	var args map[string]any
+	var s string
+	if json.Unmarshal(f.Arguments, &s) == nil {
+		json.Unmarshal([]byte(s), &args)
+	} else {
+		json.Unmarshal(f.Arguments, &args)
+	}
+	return args
+}
+
+// argumentsFragment returns a streamed fragment of the arguments as text
+func (f hfFunctionCall) argumentsFragment() string {
+	var s string
+	if json.Unmarshal(f.Arguments, &s) == nil {
+		return s
+	}
+	if len(f.Arguments) == 0 || string(f.Arguments) == "null" {
+		return ""
+	}
+	return string(f.Arguments)
+}
+
+func hfFinishReason(reason string) domain.FinishReason {
+	switch reason {
+	case "tool_calls":
+		return domain.FinishReasonToolCalls
+	case "length":
+		return domain.FinishReasonLength
+	default: // "stop", "eos_token", "stop_sequence"
+		return domain.FinishReasonStop
+	}
+}
+
+// Embed generates embeddings (not supported; Hugging Face embedding models
+// use a different task API)
+func (c *HuggingFaceClient) Embed(ctx context.Context, model string, texts []string, dimensions *int32) ([][]float32, int64, error) {
+	return nil, 0, fmt.Errorf("Hugging Face embeddings are not supported")
+}
+
+// CountTokens estimates tokens in a request
+func (c *HuggingFaceClient) CountTokens(ctx context.Context, req *domain.ChatRequest) (int32, error) {
+	var total int32
+	for _, msg := range req.Messages {
+		for _, block := range msg.Content {
+			if block.Type == "text" {
+				total += int32(len(block.Text) / 4)
+			}
+		}
+	}
+	return total, nil
+}
+
+// ListModels lists the configured endpoints and the models the serverless
+// Inference API serves. Serverless models are priced by the cheapest live
+// inference provider; if they can't be listed, only the endpoints are.
+func (c *HuggingFaceClient) ListModels(ctx context.Context) ([]domain.ModelInfo, error) {
+	models := make([]domain.ModelInfo, 0, len(c.endpoints))
+	for _, e := range c.endpoints {
+		models = append(models, e.ModelInfo())
+	}
+
+	serverless, err := c.listServerlessModels(ctx)
+	if err != nil {
+		if len(models) == 0 {
+			return nil, err
+		}
+	}
+	for _, m := range serverless {
+		if _, ok := c.endpoints[c.modelID(m.ID)]; !ok {
+			models = append(models, m)
+		}
+	}
+
+	sort.Slice(models, func(i, j int) bool { return models[i].ID < models[j].ID })
+	return models, nil
+}
+
+func (c *HuggingFaceClient) listServerlessModels(ctx context.Context) ([]domain.ModelInfo, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/models", nil)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, NewHTTPError(c.Provider(), resp.StatusCode, bodyBytes)
+	}
+
+	var result struct {
+		Data []struct {
+			ID        string `json:"id"`
+			Providers []struct {
+				Status        string `json:"status"`
+				ContextLength uint32 `json:"context_length"`
+				Pricing       *struct {
+					Input  float64 `json:"input"`
+					Output float64 `json:"output"`
+				} `json:"pricing"`
+				SupportsTools bool `json:"supports_tools"`
+			} `json:"providers"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	models := make([]domain.ModelInfo, 0, len(result.Data))
+	for _, m := range result.Data {
+		info := domain.ModelInfo{
+			ID:            string(domain.ProviderHuggingFace) + "/" + m.ID,
+			Name:          m.ID,
+			Provider:      domain.ProviderHuggingFace,
+			Enabled:       true,
+			NativeModelID: m.ID,
+		}
+		cheapest := math.Inf(1)
+		for _, p := range m.Providers {
+			if p.Status != "" && p.Status != "live" {
+				continue
+			}
+			info.SupportsTools = info.SupportsTools || p.SupportsTools
+			info.ContextLimit = max(info.ContextLimit, p.ContextLength)
+			if p.Pricing != nil && p.Pricing.Input+p.Pricing.Output < cheapest {
+				cheapest = p.Pricing.Input + p.Pricing.Output
+				info.InputCostPer1M = p.Pricing.Input
+				info.OutputCostPer1M = p.Pricing.Output
+			}
+		}
+		models = append(models, info)
+	}
+	return models, nil
+}
+
+// Helper methods
+
+// do sends a chat completions request and returns the response if it
+// succeeded. An endpoint that is scaled to zero or a serverless model that
+// is loading answers 503 with an estimated_time, used as the retry delay.
+func (c *HuggingFaceClient) do(ctx context.Context, t *hfTarget, body map[string]any) (*http.Response, error) {
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	observeQuota(ctx, resp.Header)
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, hfHTTPError(resp.StatusCode, bodyBytes)
+	}
+	return resp, nil
+}
+
+// hfHTTPError converts an error response, keeping how long Hugging Face
+// expects a cold model or endpoint to take to come up
+func hfHTTPError(status int, body []byte) *domain.ProviderError {
+	pe := NewHTTPError(domain.ProviderHuggingFace, status, body)
+	var loading struct {
+		EstimatedTime float64 `json:"estimated_time"`
+	}
+	if json.Unmarshal(body, &loading) == nil && loading.EstimatedTime > 0 {
+		pe.RetryAfter = time.Duration(math.Ceil(loading.EstimatedTime)) * time.Second
+	}
+	return pe
+}
+
+func (c *HuggingFaceClient) buildRequest(t *hfTarget, req *domain.ChatRequest) map[string]any {
+	body := map[string]any{
+		"model":    t.model,
+		"messages": openAICompatibleMessages(req),
+	}
+
+	if req.Temperature != nil {
+		body["temperature"] = *req.Temperature
+	}
+	if req.TopP != nil {
+		body["top_p"] = *req.TopP
+	}
+	if req.MaxTokens != nil {
+		body["max_tokens"] = *req.MaxTokens
+	}
+	if len(req.Tools) > 0 {
+		tools := make([]map[string]any, len(req.Tools))
+		for i, tool := range req.Tools {
+			tools[i] = map[string]any{
+				"type": "function",
+				"function": map[string]any{
+					"name":        tool.Function.Name,
+					"description": tool.Function.Description,
+					"parameters":  tool.Function.Parameters,
+				},
+			}
+		}
+		body["tools"] = tools
+		if tc := openAIToolChoice(req.ToolChoice); tc != nil {
+			body["tool_choice"] = tc
+		}
+	}
+	if rf := openAIResponseFormat(req.ResponseFormat); rf != nil {
+		body["response_format"] = rf
+	}
+
+	return body
+}
+
+// processSSEStream reads TGI-style SSE chunks. TGI sends one generated token
+// per chunk with a null finish_reason until the last, reports failures as
+// an {"error": ...} event in the stream, and versions without
+// stream_options send no usage, in which case completion tokens are counted
+// from the chunks and the prompt is estimated. Tool call arguments arrive
+// as fragments accumulated by index.
+func (c *HuggingFaceClient) processSSEStream(body io.Reader, events chan<- domain.StreamEvent, estimatedPrompt int32, cost func() float64) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var usage *domain.UsageEvent
+	var tokenChunks int32
+	var finishReason domain.FinishReason
+	var toolCalls toolCallStream
+
+	finish := func() {
+		toolCalls.flush(events)
+		if usage == nil {
+			usage = &domain.UsageEvent{PromptTokens: estimatedPrompt, CompletionTokens: tokenChunks}
+		}
+		usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+		usage.CostUSD = cost()
+		events <- *usage
+		if finishReason == "" {
+			finishReason = domain.FinishReasonStop
+		}
+		events <- domain.FinishEvent{Reason: finishReason}
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			finish()
+			return
+		}
+
+		var chunk struct {
+			Choices []struct {
+				Delta struct {
+					Content   string `json:"content"`
+					ToolCalls []struct {
+						Index    int            `json:"index"`
+						ID       string         `json:"id"`
+						Function hfFunctionCall `json:"function"`
+					} `json:"tool_calls"`
+				} `json:"delta"`
+				FinishReason *string `json:"finish_reason"`
+			} `json:"choices"`
+			Usage *struct {
+				PromptTokens     int32 `json:"prompt_tokens"`
+				CompletionTokens int32 `json:"completion_tokens"`
+			} `json:"usage"`
+			Error     string `json:"error"`
+			ErrorType string `json:"error_type"`
+		}
+
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			continue
+		}
+
+		if chunk.Error != "" {
+			events <- domain.ErrorEvent{Err: domain.NewProviderError(domain.ProviderHuggingFace,
+				codeFromText(chunk.ErrorType+" "+chunk.Error), chunk.Error)}
+			events <- domain.FinishEvent{Reason: domain.FinishReasonError}
+			return
+		}
+
+		if chunk.Usage != nil {
+			usage = &domain.UsageEvent{PromptTokens: chunk.Usage.PromptTokens, CompletionTokens: chunk.Usage.CompletionTokens}
+		}
+
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+		tokenChunks++
+
+		delta := chunk.Choices[0].Delta
+		if delta.Content != "" {
+			events <- domain.TextChunk{Content: delta.Content}
+		}
+
+		for _, tc := range delta.ToolCalls {
+			toolCalls.add(events, tc.Index, tc.ID, tc.Function.Name, tc.Function.argumentsFragment())
+		}
+
+		if fr := chunk.Choices[0].FinishReason; fr != nil && *fr != "" {
+			finishReason = hfFinishReason(*fr)
+		}
+	}
+
+	finish()
+}
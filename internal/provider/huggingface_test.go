@@ -0,0 +1,105 @@
+package provider
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+func TestHuggingFaceStream(t *testing.T) {
+	// TGI without stream_options: a token per chunk, no usage
+	stream := strings.Join([]string{
+		`data: {"choices":[{"delta":{"role":"assistant","content":"Hello"},"finish_reason":null}]}`,
+		`data: {"choices":[{"delta":{"role":"assistant","content":" there"},"finish_reason":null}]}`,
+		`data: {"choices":[{"delta":{"role":"assistant","content":"!"},"finish_reason":"eos_token"}]}`,
+		`data: [DONE]`,
+	}, "\n\n") + "\n\n"
+	events := collect(func(events chan<- domain.StreamEvent) {
+		(&HuggingFaceClient{}).processSSEStream(strings.NewReader(stream), events, 12, func() float64 { return 0.5 })
+	})
+
+	want := []domain.StreamEvent{
+		domain.TextChunk{Content: "Hello"},
+		domain.TextChunk{Content: " there"},
+		domain.TextChunk{Content: "!"},
+		domain.UsageEvent{PromptTokens: 12, CompletionTokens: 3, TotalTokens: 15, CostUSD: 0.5},
+		domain.FinishEvent{Reason: domain.FinishReasonStop},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("expected %d events, got %+v", len(want), events)
+	}
+	for i, w := range want {
+		if events[i] != w {
+			t.Errorf("event %d: expected %+v, got %+v", i, w, events[i])
+		}
+	}
+
+	// Errors during generation arrive as an event in the stream
+	stream = `data: {"choices":[{"delta":{"content":"Hi"},"finish_reason":null}]}` + "\n\n" +
+		`data: {"error":"Input validation error: inputs tokens + max_new_tokens must be <= 4096","error_type":"validation"}` + "\n\n"
+	events = collect(func(events chan<- domain.StreamEvent) {
+		(&HuggingFaceClient{}).processSSEStream(strings.NewReader(stream), events, 0, func() float64 { return 0 })
+	})
+	if len(events) != 3 {
+		t.Fatalf("expected text, error and finish, got %+v", events)
+	}
+	if _, ok := events[1].(domain.ErrorEvent); !ok {
+		t.Fatalf("expected an error event, got %+v", events[1])
+	}
+	if events[2] != (domain.FinishEvent{Reason: domain.FinishReasonError}) {
+		t.Fatalf("expected an error finish, got %+v", events[2])
+	}
+}
+
+func TestHuggingFaceTargets(t *testing.T) {
+	client, err := NewHuggingFaceClient(HuggingFaceConfig{
+		APIKey: "hf_test",
+		Endpoints: []domain.HuggingFaceEndpoint{
+			{Name: "llama-prod", URL: "https://xyz.endpoints.huggingface.cloud/", HourlyCostUSD: 3.6, Enabled: true},
+			{Name: "paused", URL: "https://abc.endpoints.huggingface.cloud", Enabled: false},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !client.SupportsModel("huggingface/llama-prod") || !client.SupportsModel("huggingface/Qwen/Qwen2.5-72B-Instruct") {
+		t.Fatal("expected endpoints and Hub model IDs to be supported")
+	}
+	if client.SupportsModel("huggingface/paused") {
+		t.Fatal("expected disabled endpoints to be skipped")
+	}
+
+	target, err := client.target(&domain.ChatRequest{Model: "huggingface/llama-prod"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target.url != "https://xyz.endpoints.huggingface.cloud/v1/chat/completions" || target.model != "tgi" {
+		t.Fatalf("unexpected endpoint target %+v", target)
+	}
+	// Ten seconds of a $3.60/hour endpoint
+	if got := target.cost(10 * time.Second); math.Abs(got-0.01) > 1e-9 {
+		t.Fatalf("expected cost 0.01, got %f", got)
+	}
+
+	if _, err := client.target(&domain.ChatRequest{Model: "huggingface/llama-prod", Tools: []domain.Tool{{}}}); err == nil {
+		t.Fatal("expected tools to be refused by an endpoint without tool support")
+	}
+
+	target, err = client.target(&domain.ChatRequest{Model: "huggingface/meta-llama/Llama-3.1-8B-Instruct"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target.url != huggingFaceRouterURL+"/chat/completions" || target.model != "meta-llama/Llama-3.1-8B-Instruct" || target.cost(time.Hour) != 0 {
+		t.Fatalf("unexpected serverless target %+v", target)
+	}
+
+	// A cold model reports how long it needs to load
+	pe := hfHTTPError(503, []byte(`{"error":"Model is currently loading","estimated_time":20.4}`))
+	if pe.RetryAfter != 21*time.Second {
+		t.Fatalf("expected a 21s retry delay, got %v", pe.RetryAfter)
+	}
+}
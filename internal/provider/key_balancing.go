@@ -0,0 +1,162 @@
+package provider
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+// Load balancing tuning
+const (
+	authErrorCooldown = 5 * time.Minute // How long a key is skipped after a 401/403
+	minKeyWeight      = 0.01            // Weight of a key at zero health, so it still gets probe traffic
+)
+
+// keyCooldowns are the errors that take a key out of rotation, and for how long
+var keyCooldowns = map[string]time.Duration{
+	ErrorTypeRateLimit: rateLimitCooldown,
+	ErrorTypeAuthError: authErrorCooldown,
+}
+
+// inCooldown checks if a key is skipped after a recent 429 or 401
+func (ks *KeySelector) inCooldown(key *ProviderAPIKey, now time.Time) bool {
+	return key.CooldownUntil != nil && now.Before(*key.CooldownUntil)
+}
+
+// availableAt returns when a rate limited or cooling down key can be used again
+func (ks *KeySelector) availableAt(key *ProviderAPIKey, now time.Time) time.Time {
+	var at time.Time
+	if ks.isRateLimited(key) {
+		at = *key.RateLimitResetAt
+	}
+	if ks.inCooldown(key, now) && key.CooldownUntil.After(at) {
+		at = *key.CooldownUntil
+	}
+	return at
+}
+
+// selectEarliestAvailable picks the key that becomes usable first when none
+// is usable now
+func (ks *KeySelector) selectEarliestAvailable(keys []*ProviderAPIKey, now time.Time) (*ProviderAPIKey, string) {
+	earliest := keys[0]
+	earliestAt := ks.availableAt(earliest, now)
+	for _, key := range keys[1:] {
+		if at := ks.availableAt(key, now); at.Before(earliestAt) {
+			earliest, earliestAt = key, at
+		}
+	}
+	if ks.inCooldown(earliest, now) {
+		return earliest, KeySelectionCooldown
+	}
+	return earliest, KeySelectionRateLimited
+}
+
+// balance picks a key from the top priority group. Canary keys take their
+// traffic share first; the strategy spreads the rest.
+func (ks *KeySelector) balance(provider domain.Provider, strategy domain.KeyStrategy, keys []*ProviderAPIKey) (*ProviderAPIKey, string) {
+	top := topPriorityKeys(keys)
+	if key, ok := pickWeighted(top, rand.IntN(100)); ok {
+		return key, KeySelectionWeighted
+	}
+	top = unweightedKeys(top)
+
+	switch strategy {
+	case domain.KeyStrategyLeastErrors:
+		return ks.roundRobin(provider, ks.leastErrorKeys(top)), KeySelectionLeastErrors
+	case domain.KeyStrategyWeighted:
+		return pickByHealth(top, rand.Float64()), KeySelectionHealthWeighted
+	default:
+		return ks.roundRobin(provider, top), KeySelectionRoundRobin
+	}
+}
+
+// topPriorityKeys returns the keys with the highest priority (lowest number)
+func topPriorityKeys(keys []*ProviderAPIKey) []*ProviderAPIKey {
+	minPriority := keys[0].Priority
+	for _, key := range keys[1:] {
+		minPriority = min(minPriority, key.Priority)
+	}
+	var top []*ProviderAPIKey
+	for _, key := range keys {
+		if key.Priority == minPriority {
+			top = append(top, key)
+		}
+	}
+	return top
+}
+
+// roundRobin takes the keys in turn
+func (ks *KeySelector) roundRobin(provider domain.Provider, keys []*ProviderAPIKey) *ProviderAPIKey {
+	if len(keys) == 1 {
+		return keys[0]
+	}
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	cacheKey := string(provider) // Single-tenant: just use provider name
+	idx := ks.roundRobinIdx[cacheKey]
+	ks.roundRobinIdx[cacheKey] = idx + 1
+	return keys[idx%len(keys)]
+}
+
+// leastErrorKeys returns the keys with the lowest recent error rate on this
+// instance, ties broken by health score
+func (ks *KeySelector) leastErrorKeys(keys []*ProviderAPIKey) []*ProviderAPIKey {
+	ks.mu.RLock()
+	rates := make([]float64, len(keys))
+	for i, key := range keys {
+		if w, ok := ks.windows[key.ID]; ok {
+			rates[i] = w.errorRate()
+		}
+	}
+	ks.mu.RUnlock()
+
+	var best []*ProviderAPIKey
+	bestRate, bestHealth := 0.0, 0.0
+	for i, key := range keys {
+		switch {
+		case best == nil || rates[i] < bestRate || (rates[i] == bestRate && key.HealthScore > bestHealth):
+			best = []*ProviderAPIKey{key}
+			bestRate, bestHealth = rates[i], key.HealthScore
+		case rates[i] == bestRate && key.HealthScore == bestHealth:
+			best = append(best, key)
+		}
+	}
+	return best
+}
+
+// pickByHealth chooses a key with probability proportional to its health
+// score. roll is uniform in [0, 1).
+func pickByHealth(keys []*ProviderAPIKey, roll float64) *ProviderAPIKey {
+	total := 0.0
+	for _, key := range keys {
+		total += max(key.HealthScore, minKeyWeight)
+	}
+	target := roll * total
+	for _, key := range keys {
+		target -= max(key.HealthScore, minKeyWeight)
+		if target < 0 {
+			return key
+		}
+	}
+	return keys[len(keys)-1]
+}
+
+// RecordUsage adds a request's tokens and cost to the key that served it
+func (ks *KeySelector) RecordUsage(ctx context.Context, tenantSlug, keyID string, inputTokens, outputTokens int64, costUSD float64) {
+	if keyID == "" {
+		return
+	}
+	db, err := ks.getTenantDB(tenantSlug)
+	if err != nil {
+		return // Silently fail
+	}
+	_, _ = db.ExecContext(ctx, `
+		UPDATE provider_api_keys
+		SET input_tokens = input_tokens + $2,
+		    output_tokens = output_tokens + $3,
+		    cost_usd = cost_usd + $4
+		WHERE id = $1
+	`, keyID, inputTokens, outputTokens, costUSD)
+}
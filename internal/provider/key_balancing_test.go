@@ -0,0 +1,91 @@
+package provider
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+func TestBalanceStrategies(t *testing.T) {
+	ks := NewKeySelector(nil)
+	a := &ProviderAPIKey{ID: "a", Priority: 1, HealthScore: 1}
+	b := &ProviderAPIKey{ID: "b", Priority: 1, HealthScore: 0.5}
+	backup := &ProviderAPIKey{ID: "backup", Priority: 2, HealthScore: 1}
+	keys := []*ProviderAPIKey{a, b, backup}
+
+	// Round-robin alternates within the top priority group
+	seen := map[string]int{}
+	for i := 0; i < 4; i++ {
+		key, selection := ks.balance(domain.ProviderOpenAI, "", keys)
+		if selection != KeySelectionRoundRobin {
+			t.Fatalf("expected round_robin, got %s", selection)
+		}
+		seen[key.ID]++
+	}
+	if seen["a"] != 2 || seen["b"] != 2 {
+		t.Fatalf("expected an even split over the top priority keys, got %v", seen)
+	}
+
+	// Least errors prefers the key with the lower recent error rate
+	ks.mu.Lock()
+	ks.window("a").add(true)
+	ks.window("b").add(false)
+	ks.mu.Unlock()
+	for i := 0; i < 3; i++ {
+		if key, selection := ks.balance(domain.ProviderOpenAI, domain.KeyStrategyLeastErrors, keys); key != b || selection != KeySelectionLeastErrors {
+			t.Fatalf("expected b by least errors, got %s (%s)", key.ID, selection)
+		}
+	}
+
+	// Canary keys still take their share first
+	canary := &ProviderAPIKey{ID: "canary", Priority: 1, TrafficPercent: percent(100)}
+	if key, selection := ks.balance(domain.ProviderOpenAI, domain.KeyStrategyLeastErrors, []*ProviderAPIKey{a, canary}); key != canary || selection != KeySelectionWeighted {
+		t.Fatalf("expected the canary, got %s (%s)", key.ID, selection)
+	}
+}
+
+func TestPickByHealth(t *testing.T) {
+	healthy := &ProviderAPIKey{ID: "healthy", HealthScore: 0.75}
+	degraded := &ProviderAPIKey{ID: "degraded", HealthScore: 0.25}
+	keys := []*ProviderAPIKey{healthy, degraded}
+
+	counts := map[string]int{}
+	for i := 0; i < 100; i++ {
+		counts[pickByHealth(keys, float64(i)/100).ID]++
+	}
+	if counts["healthy"] != 75 || counts["degraded"] != 25 {
+		t.Fatalf("expected a 75/25 split, got %v", counts)
+	}
+
+	// A key at zero health still gets a little traffic
+	dead := &ProviderAPIKey{ID: "dead"}
+	if key := pickByHealth([]*ProviderAPIKey{healthy, dead}, 0.999); key != dead {
+		t.Fatalf("expected the last roll to probe the dead key, got %s", key.ID)
+	}
+}
+
+func TestSelectEarliestAvailable(t *testing.T) {
+	ks := NewKeySelector(nil)
+	now := time.Now()
+	zero := 0
+	limited := &ProviderAPIKey{ID: "limited", RateLimitRemaining: &zero, RateLimitResetAt: ptrTime(now.Add(30 * time.Second))}
+	rejected := &ProviderAPIKey{ID: "rejected", CooldownUntil: ptrTime(now.Add(5 * time.Minute)), CooldownReason: ErrorTypeAuthError}
+	cooling := &ProviderAPIKey{ID: "cooling", CooldownUntil: ptrTime(now.Add(10 * time.Second)), CooldownReason: ErrorTypeRateLimit}
+
+	if !ks.inCooldown(rejected, now) || ks.inCooldown(limited, now) {
+		t.Fatal("unexpected cooldown state")
+	}
+	if key, selection := ks.selectEarliestAvailable([]*ProviderAPIKey{limited, rejected, cooling}, now); key != cooling || selection != KeySelectionCooldown {
+		t.Fatalf("expected the key whose cooldown ends first, got %s (%s)", key.ID, selection)
+	}
+	if key, selection := ks.selectEarliestAvailable([]*ProviderAPIKey{rejected, limited}, now); key != limited || selection != KeySelectionRateLimited {
+		t.Fatalf("expected the rate limited key, got %s (%s)", key.ID, selection)
+	}
+	if _, ok := keyCooldowns[ClassifyError(errors.New("status 401: invalid api key"))]; !ok {
+		t.Fatal("expected a 401 to start a cooldown")
+	}
+}
+
+func ptrTime(t time.Time) *time.Time { return &t }
@@ -0,0 +1,267 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+// Canary rollback tuning
+const (
+	rollbackWindowSize  = 100             // Outcomes kept per key
+	rollbackMinRequests = 20              // Outcomes needed before a key can be rolled back
+	rateLimitCooldown   = 1 * time.Minute // How long a key is skipped after a 429
+	quotaLowWater       = 0.05            // Share of a provider quota window below which a key is avoided
+)
+
+// keyWindow is a ring buffer of a key's most recent request outcomes, plus the
+// rollout settings seen when the key was last selected
+type keyWindow struct {
+	outcomes [rollbackWindowSize]bool // true = failure
+	next     int
+	count    int
+	failures int
+
+	canary            bool
+	rollbackErrorRate float64
+}
+
+func (w *keyWindow) add(failed bool) {
+	if w.count == rollbackWindowSize {
+		if w.outcomes[w.next] {
+			w.failures--
+		}
+	} else {
+		w.count++
+	}
+	w.outcomes[w.next] = failed
+	if failed {
+		w.failures++
+	}
+	w.next = (w.next + 1) % rollbackWindowSize
+}
+
+func (w *keyWindow) errorRate() float64 {
+	if w.count == 0 {
+		return 0
+	}
+	return float64(w.failures) / float64(w.count)
+}
+
+// pickWeighted chooses among keys with a traffic percentage. roll is uniform in
+// [0, 100). It returns false when the roll falls in the share left to keys
+// without a percentage.
+func pickWeighted(keys []*ProviderAPIKey, roll int) (*ProviderAPIKey, bool) {
+	total, unweighted := 0, 0
+	for _, k := range keys {
+		if k.TrafficPercent == nil {
+			unweighted++
+		} else if *k.TrafficPercent > 0 {
+			total += *k.TrafficPercent
+		}
+	}
+	if total == 0 {
+		return nil, false
+	}
+	if total > 100 {
+		total = 100
+	}
+	if unweighted == 0 {
+		// Only weighted keys: percentages are relative to each other
+		roll = roll * total / 100
+	} else if roll >= total {
+		return nil, false
+	}
+
+	cum := 0
+	for _, k := range keys {
+		if k.TrafficPercent == nil || *k.TrafficPercent <= 0 {
+			continue
+		}
+		cum += *k.TrafficPercent
+		if roll < cum {
+			return k, true
+		}
+	}
+	return nil, false
+}
+
+// unweightedKeys returns the keys that share the traffic not claimed by a
+// percentage, or all keys if every key has one
+func unweightedKeys(keys []*ProviderAPIKey) []*ProviderAPIKey {
+	var out []*ProviderAPIKey
+	for _, k := range keys {
+		if k.TrafficPercent == nil {
+			out = append(out, k)
+		}
+	}
+	if len(out) == 0 {
+		return keys
+	}
+	return out
+}
+
+// trackRollout remembers which keys are canaries so RecordOutcome can roll them back
+func (ks *KeySelector) trackRollout(keys []*ProviderAPIKey) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	for _, k := range keys {
+		w := ks.window(k.ID)
+		w.canary = k.TrafficPercent != nil && *k.TrafficPercent > 0
+		w.rollbackErrorRate = k.RollbackErrorRate
+	}
+}
+
+// window returns the outcome window for a key; callers hold ks.mu
+func (ks *KeySelector) window(keyID string) *keyWindow {
+	w, ok := ks.windows[keyID]
+	if !ok {
+		w = &keyWindow{}
+		ks.windows[keyID] = w
+	}
+	return w
+}
+
+// ClassifyError maps a provider error to one of the ErrorType constants
+func ClassifyError(err error) string {
+	msg := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(msg, "429") || strings.Contains(msg, "rate limit") || strings.Contains(msg, "quota"):
+		return ErrorTypeRateLimit
+	case strings.Contains(msg, "401") || strings.Contains(msg, "403") || strings.Contains(msg, "unauthorized") || strings.Contains(msg, "invalid api key"):
+		return ErrorTypeAuthError
+	case strings.Contains(msg, "timeout") || strings.Contains(msg, "deadline exceeded"):
+		return ErrorTypeTimeout
+	default:
+		return ErrorTypeServer
+	}
+}
+
+// RecordOutcome updates a key's health and counters after a request and rolls
+// a canary key back to 0% traffic once its recent error rate crosses its
+// threshold. err is nil for a successful request.
+func (ks *KeySelector) RecordOutcome(ctx context.Context, tenantSlug, keyID string, err error) {
+	if keyID == "" {
+		return
+	}
+	db, dbErr := ks.getTenantDB(tenantSlug)
+	if dbErr != nil {
+		return // Silently fail
+	}
+
+	if err == nil {
+		_, _ = db.ExecContext(ctx, `
+			UPDATE provider_api_keys
+			SET success_count = success_count + 1,
+			    health_score = LEAST(1.0, health_score + $2),
+			    updated_at = NOW()
+			WHERE id = $1
+		`, keyID, HealthRecoveryRate)
+	} else {
+		errorType := ClassifyError(err)
+		_, _ = db.ExecContext(ctx, `
+			UPDATE provider_api_keys
+			SET failure_count = failure_count + 1,
+			    health_score = GREATEST(0.0, health_score - $2),
+			    updated_at = NOW()
+			WHERE id = $1
+		`, keyID, getHealthPenalty(errorType))
+		if cooldown, ok := keyCooldowns[errorType]; ok {
+			// Out of quota or rejected: skip the key until the cooldown passes
+			_, _ = db.ExecContext(ctx, `
+				UPDATE provider_api_keys
+				SET cooldown_until = $2, cooldown_reason = $3
+				WHERE id = $1
+			`, keyID, time.Now().Add(cooldown), errorType)
+		}
+	}
+
+	ks.mu.Lock()
+	w := ks.window(keyID)
+	w.add(err != nil)
+	rollback := w.canary && w.rollbackErrorRate > 0 && w.count >= rollbackMinRequests && w.errorRate() >= w.rollbackErrorRate
+	rate, count, threshold := w.errorRate(), w.count, w.rollbackErrorRate
+	if rollback {
+		delete(ks.windows, keyID)
+	}
+	ks.mu.Unlock()
+
+	if rollback {
+		reason := fmt.Sprintf("error rate %.0f%% over the last %d requests reached the %.0f%% rollback threshold", rate*100, count, threshold*100)
+		slog.Warn("Rolling back canary provider key", "key_id", keyID, "reason", reason)
+		_, _ = db.ExecContext(ctx, `
+			UPDATE provider_api_keys
+			SET traffic_percent = 0,
+			    rolled_back_at = NOW(),
+			    rollback_reason = $2,
+			    updated_at = NOW()
+			WHERE id = $1 AND traffic_percent > 0
+		`, keyID, reason)
+	}
+}
+
+// RecentStats returns the number of recent requests tracked for a key and
+// their error rate
+func (ks *KeySelector) RecentStats(keyID string) (int, float64) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	w, ok := ks.windows[keyID]
+	if !ok {
+		return 0, 0
+	}
+	return w.count, w.errorRate()
+}
+
+// SetTraffic sets a key's traffic percentage (nil = share the remainder) and
+// rollback threshold, clearing any earlier rollback and the key's recent outcomes
+func (ks *KeySelector) SetTraffic(ctx context.Context, tenantSlug, keyID string, percent *int, rollbackErrorRate float64) error {
+	if percent != nil && (*percent < 0 || *percent > 100) {
+		return fmt.Errorf("traffic percent must be between 0 and 100")
+	}
+	if rollbackErrorRate < 0 || rollbackErrorRate > 1 {
+		return fmt.Errorf("rollback error rate must be between 0 and 1")
+	}
+	db, err := ks.getTenantDB(tenantSlug)
+	if err != nil {
+		return err
+	}
+	res, err := db.ExecContext(ctx, `
+		UPDATE provider_api_keys
+		SET traffic_percent = $2,
+		    rollback_error_rate = $3,
+		    rolled_back_at = NULL,
+		    rollback_reason = NULL,
+		    updated_at = NOW()
+		WHERE id = $1
+	`, keyID, percent, rollbackErrorRate)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrKeyNotFound
+	}
+
+	ks.mu.Lock()
+	delete(ks.windows, keyID)
+	ks.mu.Unlock()
+	return nil
+}
+
+// KeyProvider returns the provider a stored key belongs to
+func (ks *KeySelector) KeyProvider(ctx context.Context, tenantSlug, keyID string) (domain.Provider, error) {
+	db, err := ks.getTenantDB(tenantSlug)
+	if err != nil {
+		return "", err
+	}
+	var provider string
+	err = db.QueryRowContext(ctx, `SELECT provider FROM provider_api_keys WHERE id = $1`, keyID).Scan(&provider)
+	if err == sql.ErrNoRows {
+		return "", ErrKeyNotFound
+	}
+	return domain.Provider(provider), err
+}
@@ -0,0 +1,74 @@
+package provider
+
+import (
+	"errors"
+	"testing"
+)
+
+func percent(p int) *int { return &p }
+
+func TestPickWeightedSplitsTraffic(t *testing.T) {
+	stable := &ProviderAPIKey{ID: "stable"}
+	canary := &ProviderAPIKey{ID: "canary", TrafficPercent: percent(10)}
+	keys := []*ProviderAPIKey{stable, canary}
+
+	picked := 0
+	for roll := 0; roll < 100; roll++ {
+		if key, ok := pickWeighted(keys, roll); ok {
+			if key != canary {
+				t.Fatalf("roll %d picked %s", roll, key.ID)
+			}
+			picked++
+		}
+	}
+	if picked != 10 {
+		t.Fatalf("expected the canary on 10 of 100 rolls, got %d", picked)
+	}
+	if rest := unweightedKeys(keys); len(rest) != 1 || rest[0] != stable {
+		t.Fatalf("expected the remainder to go to the stable key, got %v", rest)
+	}
+
+	// A rolled-back canary gets nothing
+	canary.TrafficPercent = percent(0)
+	for roll := 0; roll < 100; roll++ {
+		if _, ok := pickWeighted(keys, roll); ok {
+			t.Fatalf("roll %d picked a key at 0%%", roll)
+		}
+	}
+
+	// With only weighted keys the percentages are relative to each other
+	a := &ProviderAPIKey{ID: "a", TrafficPercent: percent(30)}
+	b := &ProviderAPIKey{ID: "b", TrafficPercent: percent(10)}
+	counts := map[string]int{}
+	for roll := 0; roll < 100; roll++ {
+		key, ok := pickWeighted([]*ProviderAPIKey{a, b}, roll)
+		if !ok {
+			t.Fatalf("roll %d picked no key", roll)
+		}
+		counts[key.ID]++
+	}
+	if counts["a"] != 75 || counts["b"] != 25 {
+		t.Fatalf("expected a 75/25 split, got %v", counts)
+	}
+}
+
+func TestKeyWindowErrorRate(t *testing.T) {
+	var w keyWindow
+	for i := 0; i < rollbackWindowSize; i++ {
+		w.add(i%2 == 0)
+	}
+	if w.errorRate() != 0.5 {
+		t.Fatalf("expected 0.5, got %v", w.errorRate())
+	}
+	// Older outcomes fall out of the window
+	for i := 0; i < rollbackWindowSize; i++ {
+		w.add(false)
+	}
+	if w.count != rollbackWindowSize || w.errorRate() != 0 {
+		t.Fatalf("expected a full window without failures, got %d at %v", w.count, w.errorRate())
+	}
+
+	if got := ClassifyError(errors.New("openai: 429 Too Many Requests")); got != ErrorTypeRateLimit {
+		t.Fatalf("expected rate_limit, got %s", got)
+	}
+}
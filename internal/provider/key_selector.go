@@ -3,6 +3,7 @@ package provider
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -27,6 +28,9 @@ const (
 	ErrorTypeServer    = "server_error"
 )
 
+// ErrKeyNotFound is returned for an unknown provider API key ID
+var ErrKeyNotFound = errors.New("provider API key not found")
+
 // ProviderAPIKey represents a single API key for a provider
 type ProviderAPIKey struct {
 	ID       string
@@ -54,8 +58,40 @@ type ProviderAPIKey struct {
 	LastUsedAt         *time.Time
 	CreatedAt          time.Time
 	UpdatedAt          time.Time
+
+	// Canary rollout
+	TrafficPercent    *int       // Share of its priority group's traffic; nil = split the remainder
+	RollbackErrorRate float64    // Recent error rate that sets TrafficPercent to 0; 0 = never
+	RolledBackAt      *time.Time // When the key was last rolled back automatically
+	RollbackReason    string
+
+	// Last "test connection" result
+	LastValidatedAt         *time.Time
+	LastValidationOK        *bool
+	LastValidationError     string
+	LastValidationLatencyMs *int
+
+	// Load balancing
+	CooldownUntil  *time.Time // Skipped until then after a 429 or 401
+	CooldownReason string     // ErrorTypeRateLimit or ErrorTypeAuthError
+	InputTokens    int64      // Usage served by the key
+	OutputTokens   int64
+	CostUSD        float64
+
+	Selection string // Why SelectKey chose this key (one of the KeySelection constants)
 }
 
+// Reasons SelectKey gives for choosing a key
+const (
+	KeySelectionRoundRobin     = "round_robin"     // Round-robin within the top priority group
+	KeySelectionLeastErrors    = "least_errors"    // Lowest recent error rate in the top priority group
+	KeySelectionHealthWeighted = "health_weighted" // Random in proportion to health in the top priority group
+	KeySelectionWeighted       = "weighted"        // Canary traffic percentage
+	KeySelectionQuotaLow       = "quota_low"       // Every usable key is close to its provider quota
+	KeySelectionRateLimited    = "rate_limited"    // Every key is rate limited; earliest reset wins
+	KeySelectionCooldown       = "cooldown"        // Every key is unusable; the earliest cooldown to end wins
+)
+
 // TenantDBProvider is a function that returns the database for a given tenant slug
 type TenantDBProvider func(tenantSlug string) (*sql.DB, error)
 
@@ -63,15 +99,31 @@ type TenantDBProvider func(tenantSlug string) (*sql.DB, error)
 type KeySelector struct {
 	getTenantDB   TenantDBProvider
 	encryption    *crypto.EncryptionService
-	roundRobinIdx map[string]int // tenant:provider -> index
+	roundRobinIdx map[string]int        // tenant:provider -> index
+	windows       map[string]*keyWindow // key ID -> recent outcomes
+	quotas        QuotaSource
 	mu            sync.RWMutex
 }
 
+// QuotaSource reports the latest upstream rate limit state for a provider key
+type QuotaSource interface {
+	KeyQuota(provider, keyID string) (domain.ProviderQuota, bool)
+}
+
+// SetQuotaSource lets SelectKey steer traffic away from keys that are close to
+// their provider quota
+func (ks *KeySelector) SetQuotaSource(src QuotaSource) {
+	ks.mu.Lock()
+	ks.quotas = src
+	ks.mu.Unlock()
+}
+
 // NewKeySelector creates a new key selector without encryption (for backwards compatibility)
 func NewKeySelector(getTenantDB TenantDBProvider) *KeySelector {
 	return &KeySelector{
 		getTenantDB:   getTenantDB,
 		roundRobinIdx: make(map[string]int),
+		windows:       make(map[string]*keyWindow),
 	}
 }
 
@@ -81,12 +133,14 @@ func NewKeySelectorWithEncryption(getTenantDB TenantDBProvider, encryption *cryp
 		getTenantDB:   getTenantDB,
 		encryption:    encryption,
 		roundRobinIdx: make(map[string]int),
+		windows:       make(map[string]*keyWindow),
 	}
 }
 
-// SelectKey chooses the best API key for a provider
+// SelectKey chooses the best API key for a provider, spreading requests across
+// the top priority keys with the provider's strategy
 // tenantSlug is used to get the database connection (single-tenant mode)
-func (ks *KeySelector) SelectKey(ctx context.Context, tenantSlug string, provider domain.Provider) (*ProviderAPIKey, error) {
+func (ks *KeySelector) SelectKey(ctx context.Context, tenantSlug string, provider domain.Provider, strategy domain.KeyStrategy) (*ProviderAPIKey, error) {
 	db, err := ks.getTenantDB(tenantSlug)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get tenant database: %w", err)
@@ -115,7 +169,9 @@ func (ks *KeySelector) SelectKey(ctx context.Context, tenantSlug string, provide
 	query := `
 		SELECT id, api_key_encrypted, access_key_id_encrypted, secret_access_key_encrypted,
 		       credential_type, name, priority, health_score,
-		       rate_limit_remaining, rate_limit_reset_at
+		       rate_limit_remaining, rate_limit_reset_at,
+		       traffic_percent, COALESCE(rollback_error_rate, 0),
+		       cooldown_until, COALESCE(cooldown_reason, '')
 		FROM provider_api_keys
 		WHERE provider = $1
 		  AND enabled = true
@@ -135,6 +191,8 @@ func (ks *KeySelector) SelectKey(ctx context.Context, tenantSlug string, provide
 		var secretAccessKeyEncrypted sql.NullString
 		var rateLimitRemaining sql.NullInt32
 		var rateLimitResetAt sql.NullTime
+		var trafficPercent sql.NullInt32
+		var cooldownUntil sql.NullTime
 
 		key.Provider = provider
 
@@ -142,10 +200,19 @@ func (ks *KeySelector) SelectKey(ctx context.Context, tenantSlug string, provide
 			&key.ID, &apiKeyEncrypted, &accessKeyIDEncrypted, &secretAccessKeyEncrypted,
 			&key.CredentialType, &key.Name, &key.Priority,
 			&key.HealthScore, &rateLimitRemaining, &rateLimitResetAt,
+			&trafficPercent, &key.RollbackErrorRate,
+			&cooldownUntil, &key.CooldownReason,
 		)
 		if err != nil {
 			continue
 		}
+		if trafficPercent.Valid {
+			pct := int(trafficPercent.Int32)
+			key.TrafficPercent = &pct
+		}
+		if cooldownUntil.Valid {
+			key.CooldownUntil = &cooldownUntil.Time
+		}
 
 		// Decrypt API key if present
 		if apiKeyEncrypted.Valid && apiKeyEncrypted.String != "" {
@@ -211,21 +278,39 @@ func (ks *KeySelector) SelectKey(ctx context.Context, tenantSlug string, provide
 		return nil, fmt.Errorf("no enabled API keys for provider %s", provider)
 	}
 
-	// Filter out rate-limited keys
+	// Filter out rate-limited keys and keys in cooldown, and keys close to
+	// their provider quota unless nothing else is left
+	now := time.Now()
 	availableKeys := make([]*ProviderAPIKey, 0, len(keys))
+	var lowKeys []*ProviderAPIKey
 	for _, key := range keys {
-		if !ks.isRateLimited(key) {
+		switch {
+		case ks.isRateLimited(key) || ks.inCooldown(key, now):
+		case ks.isQuotaLow(provider, key):
+			lowKeys = append(lowKeys, key)
+		default:
 			availableKeys = append(availableKeys, key)
 		}
 	}
+	quotaLow := len(availableKeys) == 0
+	if quotaLow {
+		availableKeys = lowKeys
+	}
 
 	if len(availableKeys) == 0 {
-		// All keys are rate-limited, use the one with earliest reset time
-		return ks.selectByResetTime(keys), nil
+		// No key is usable, use the one that becomes usable first
+		key, selection := ks.selectEarliestAvailable(keys, now)
+		key.Selection = selection
+		return key, nil
 	}
 
-	// Round-robin selection among available keys of same priority
-	selectedKey := ks.roundRobinSelect(provider, availableKeys)
+	// Canary or strategy selection among available keys of the top priority
+	selectedKey, selection := ks.balance(provider, strategy, availableKeys)
+	ks.trackRollout(availableKeys)
+	if quotaLow {
+		selection = KeySelectionQuotaLow
+	}
+	selectedKey.Selection = selection
 
 	// Mark key as used (pass tenant slug for database access)
 	go ks.recordKeyUsage(context.Background(), tenantSlug, selectedKey.ID)
@@ -233,6 +318,53 @@ func (ks *KeySelector) SelectKey(ctx context.Context, tenantSlug string, provide
 	return selectedKey, nil
 }
 
+// SelectNamedKey returns the enabled API key of a provider with the given
+// name, for regional deployments that have their own key
+func (ks *KeySelector) SelectNamedKey(ctx context.Context, tenantSlug string, provider domain.Provider, name string) (*ProviderAPIKey, error) {
+	db, err := ks.getTenantDB(tenantSlug)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant database: %w", err)
+	}
+
+	key := ProviderAPIKey{Provider: provider, Name: name}
+	var apiKeyEncrypted, accessKeyIDEncrypted, secretAccessKeyEncrypted sql.NullString
+	err = db.QueryRowContext(ctx, `
+		SELECT id, api_key_encrypted, access_key_id_encrypted, secret_access_key_encrypted, credential_type
+		FROM provider_api_keys
+		WHERE provider = $1 AND name = $2 AND enabled = true
+		ORDER BY priority ASC
+		LIMIT 1
+	`, provider, name).Scan(&key.ID, &apiKeyEncrypted, &accessKeyIDEncrypted, &secretAccessKeyEncrypted, &key.CredentialType)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("no enabled API key named %q for provider %s", name, provider)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query API key: %w", err)
+	}
+	key.APIKeyEncrypted, key.APIKeyDecrypted = ks.decrypt(apiKeyEncrypted)
+	key.AccessKeyIDEncrypted, key.AccessKeyIDDecrypted = ks.decrypt(accessKeyIDEncrypted)
+	key.SecretAccessKeyEncrypted, key.SecretAccessKeyDecrypted = ks.decrypt(secretAccessKeyEncrypted)
+
+	go ks.recordKeyUsage(context.Background(), tenantSlug, key.ID)
+	return &key, nil
+}
+
+// decrypt returns a stored credential and its decrypted value. Values that
+// don't decrypt are returned as stored, as they predate encryption.
+func (ks *KeySelector) decrypt(stored sql.NullString) (encrypted, decrypted string) {
+	if !stored.Valid || stored.String == "" {
+		return "", ""
+	}
+	if ks.encryption == nil {
+		return stored.String, stored.String
+	}
+	value, err := ks.encryption.Decrypt(stored.String)
+	if err != nil {
+		return stored.String, stored.String
+	}
+	return stored.String, value
+}
+
 // StoreKey stores a new API key with encryption
 // tenantSlug is used to get the database connection (single-tenant mode)
 func (ks *KeySelector) StoreKey(ctx context.Context, tenantSlug string, provider domain.Provider, apiKey, name string, priority int, accessKeyID, secretAccessKey string) (string, error) {
@@ -343,58 +475,17 @@ func (ks *KeySelector) isRateLimited(key *ProviderAPIKey) bool {
 	return false
 }
 
-// selectByResetTime selects key with earliest reset time
-func (ks *KeySelector) selectByResetTime(keys []*ProviderAPIKey) *ProviderAPIKey {
-	var earliest *ProviderAPIKey
-	var earliestTime time.Time
-
-	for _, key := range keys {
-		if key.RateLimitResetAt != nil {
-			if earliest == nil || key.RateLimitResetAt.Before(earliestTime) {
-				earliest = key
-				earliestTime = *key.RateLimitResetAt
-			}
-		}
-	}
-
-	if earliest != nil {
-		return earliest
-	}
-
-	return keys[0] // Fallback to first key
-}
-
-// roundRobinSelect performs round-robin selection within priority groups
-func (ks *KeySelector) roundRobinSelect(provider domain.Provider, keys []*ProviderAPIKey) *ProviderAPIKey {
-	if len(keys) == 1 {
-		return keys[0]
-	}
-
-	// Group by priority
-	priorityGroups := make(map[int][]*ProviderAPIKey)
-	for _, key := range keys {
-		priorityGroups[key.Priority] = append(priorityGroups[key.Priority], key)
-	}
-
-	// Get highest priority group (lowest number)
-	minPriority := int(^uint(0) >> 1) // Max int
-	for priority := range priorityGroups {
-		if priority < minPriority {
-			minPriority = priority
-		}
-	}
-
-	topPriorityKeys := priorityGroups[minPriority]
-
-	// Round-robin within top priority group
-	ks.mu.Lock()
-	cacheKey := string(provider) // Single-tenant: just use provider name
-	idx := ks.roundRobinIdx[cacheKey]
-	selectedKey := topPriorityKeys[idx%len(topPriorityKeys)]
-	ks.roundRobinIdx[cacheKey] = idx + 1
-	ks.mu.Unlock()
-
-	return selectedKey
+// isQuotaLow checks whether the provider's last response for a key showed it
+// about to run out of requests or tokens
+func (ks *KeySelector) isQuotaLow(provider domain.Provider, key *ProviderAPIKey) bool {
+	ks.mu.RLock()
+	src := ks.quotas
+	ks.mu.RUnlock()
+	if src == nil {
+		return false
+	}
+	q, ok := src.KeyQuota(string(provider), key.ID)
+	return ok && q.Low(quotaLowWater, time.Now())
 }
 
 // recordKeyUsage updates usage stats
@@ -517,7 +608,10 @@ func (ks *KeySelector) ListKeys(ctx context.Context, tenantSlug string, provider
 		       credential_type,
 		       name, priority, enabled,
 		       health_score, success_count, failure_count, rate_limit_remaining,
-		       rate_limit_reset_at, request_count, last_used_at, created_at, updated_at
+		       rate_limit_reset_at, request_count, last_used_at, created_at, updated_at,
+		       traffic_percent, COALESCE(rollback_error_rate, 0), rolled_back_at, COALESCE(rollback_reason, ''),
+		       last_validated_at, last_validation_ok, COALESCE(last_validation_error, ''), last_validation_latency_ms,
+		       cooldown_until, COALESCE(cooldown_reason, ''), input_tokens, output_tokens, cost_usd
 		FROM provider_api_keys
 		WHERE provider = $1
 		ORDER BY priority ASC, health_score DESC
@@ -539,6 +633,12 @@ func (ks *KeySelector) ListKeys(ctx context.Context, tenantSlug string, provider
 		var rateLimitResetAt sql.NullTime
 		var lastUsedAt sql.NullTime
 		var name sql.NullString
+		var trafficPercent sql.NullInt32
+		var rolledBackAt sql.NullTime
+		var lastValidatedAt sql.NullTime
+		var lastValidationOK sql.NullBool
+		var lastValidationLatency sql.NullInt32
+		var cooldownUntil sql.NullTime
 
 		err := rows.Scan(
 			&key.ID, &key.Provider,
@@ -548,10 +648,33 @@ func (ks *KeySelector) ListKeys(ctx context.Context, tenantSlug string, provider
 			&key.Priority, &key.Enabled, &key.HealthScore, &key.SuccessCount,
 			&key.FailureCount, &rateLimitRemaining, &rateLimitResetAt,
 			&key.RequestCount, &lastUsedAt, &key.CreatedAt, &key.UpdatedAt,
+			&trafficPercent, &key.RollbackErrorRate, &rolledBackAt, &key.RollbackReason,
+			&lastValidatedAt, &lastValidationOK, &key.LastValidationError, &lastValidationLatency,
+			&cooldownUntil, &key.CooldownReason, &key.InputTokens, &key.OutputTokens, &key.CostUSD,
 		)
 		if err != nil {
 			return nil, err
 		}
+		if cooldownUntil.Valid {
+			key.CooldownUntil = &cooldownUntil.Time
+		}
+		if trafficPercent.Valid {
+			pct := int(trafficPercent.Int32)
+			key.TrafficPercent = &pct
+		}
+		if rolledBackAt.Valid {
+			key.RolledBackAt = &rolledBackAt.Time
+		}
+		if lastValidatedAt.Valid {
+			key.LastValidatedAt = &lastValidatedAt.Time
+		}
+		if lastValidationOK.Valid {
+			key.LastValidationOK = &lastValidationOK.Bool
+		}
+		if lastValidationLatency.Valid {
+			ms := int(lastValidationLatency.Int32)
+			key.LastValidationLatencyMs = &ms
+		}
 
 		// Handle nullable fields
 		if name.Valid {
@@ -0,0 +1,174 @@
+package provider
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+// validationTimeout bounds a single key validation call
+const validationTimeout = 15 * time.Second
+
+// How a key was validated
+const (
+	ValidationMethodListModels = "list_models" // An authenticated model listing
+	ValidationMethodCompletion = "completion"  // A 1-token chat completion
+)
+
+// Capabilities reported by a key validation
+const (
+	CapabilityChat       = "chat"
+	CapabilityTools      = "tools"
+	CapabilityReasoning  = "reasoning"
+	CapabilityEmbeddings = "embeddings"
+)
+
+// validationProbes is the cheap model a 1-token completion is sent to for
+// providers whose ListModels is a built-in catalog, or (OpenRouter) an
+// unauthenticated endpoint, and so can't tell whether a key works
+var validationProbes = map[domain.Provider]string{
+	domain.ProviderAnthropic:   "anthropic/claude-3-5-haiku-20241022",
+	domain.ProviderAzureOpenAI: "azure/gpt-4o-mini",
+	domain.ProviderCohere:      "command-r",
+	domain.ProviderGroq:        "llama-3.1-8b-instant",
+	domain.ProviderMistral:     "ministral-3b-latest",
+	domain.ProviderTogether:    "meta-llama/Meta-Llama-3.1-8B-Instruct-Turbo",
+	domain.ProviderXAI:         "grok-3-mini",
+	domain.ProviderDeepSeek:    "deepseek-chat",
+	domain.ProviderHuggingFace: "meta-llama/Llama-3.1-8B-Instruct",
+	domain.ProviderOpenRouter:  "openai/gpt-4o-mini",
+}
+
+// KeyValidation is the result of checking a provider key against the provider
+type KeyValidation struct {
+	Valid        bool
+	Method       string // One of the ValidationMethod constants
+	Model        string // Model the completion was sent to (completion method only)
+	LatencyMs    int
+	ModelCount   int      // Models the key can see (list_models method only)
+	Capabilities []string // Detected capabilities, sorted
+	Error        *domain.ProviderError
+	CheckedAt    time.Time
+}
+
+// ValidateCredentials makes the cheapest call that proves providerCfg's
+// credentials work: a model listing where the provider authenticates it, and
+// otherwise a 1-token completion. It uses a fresh client, so cached tenant
+// clients and key health are not affected. A failed call is reported in the
+// result rather than as an error.
+func ValidateCredentials(ctx context.Context, provider domain.Provider, providerCfg *domain.ProviderConfig) *KeyValidation {
+	result := &KeyValidation{Method: ValidationMethodListModels, CheckedAt: time.Now()}
+	probe, useCompletion := validationProbes[provider]
+	if useCompletion {
+		result.Method = ValidationMethodCompletion
+		result.Model = probe
+		if provider == domain.ProviderAzureOpenAI && providerCfg.ExtraSettings["deployment"] != "" {
+			result.Model = "azure/" + providerCfg.ExtraSettings["deployment"]
+		}
+	}
+
+	client, err := newClient(provider, providerCfg)
+	if err != nil {
+		result.Error = domain.NewProviderError(provider, domain.ErrorCodeInvalidRequest, err.Error())
+		return result
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, validationTimeout)
+	defer cancel()
+
+	start := time.Now()
+	var models []domain.ModelInfo
+	if useCompletion {
+		maxTokens := int32(1)
+		_, err = client.ChatComplete(ctx, &domain.ChatRequest{
+			Model:     result.Model,
+			MaxTokens: &maxTokens,
+			Messages:  []domain.Message{{Role: "user", Content: []domain.ContentBlock{{Type: "text", Text: "ping"}}}},
+		})
+	} else {
+		models, err = client.ListModels(ctx)
+	}
+	result.LatencyMs = int(time.Since(start).Milliseconds())
+	if err != nil {
+		result.Error = NormalizeError(provider, err)
+		return result
+	}
+	result.Valid = true
+
+	if useCompletion && provider != domain.ProviderOpenRouter {
+		// The built-in catalog doesn't call the provider
+		models, _ = client.ListModels(ctx)
+	} else {
+		result.ModelCount = len(models)
+	}
+	result.Capabilities = detectCapabilities(models)
+	return result
+}
+
+// detectCapabilities returns what the models can do between them. Chat is
+// assumed, since the key answered a chat or model listing call.
+func detectCapabilities(models []domain.ModelInfo) []string {
+	caps := map[string]bool{CapabilityChat: true}
+	for _, m := range models {
+		if m.SupportsTools {
+			caps[CapabilityTools] = true
+		}
+		if m.SupportsReasoning {
+			caps[CapabilityReasoning] = true
+		}
+		if strings.Contains(strings.ToLower(m.ID), "embed") {
+			caps[CapabilityEmbeddings] = true
+		}
+	}
+	out := make([]string, 0, len(caps))
+	for c := range caps {
+		out = append(out, c)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// GetKey returns a stored key with its credentials decrypted
+func (ks *KeySelector) GetKey(ctx context.Context, tenantSlug, keyID string) (*ProviderAPIKey, error) {
+	provider, err := ks.KeyProvider(ctx, tenantSlug, keyID)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := ks.ListKeys(ctx, tenantSlug, provider)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		if key.ID == keyID {
+			return key, nil
+		}
+	}
+	return nil, ErrKeyNotFound
+}
+
+// RecordValidation stores the outcome of a key validation on the key. A key
+// that validates is taken out of cooldown.
+func (ks *KeySelector) RecordValidation(ctx context.Context, tenantSlug, keyID string, v *KeyValidation) error {
+	db, err := ks.getTenantDB(tenantSlug)
+	if err != nil {
+		return err
+	}
+	var validationErr *string
+	if v.Error != nil {
+		validationErr = &v.Error.Message
+	}
+	_, err = db.ExecContext(ctx, `
+		UPDATE provider_api_keys
+		SET last_validated_at = $2,
+		    last_validation_ok = $3,
+		    last_validation_error = $4,
+		    last_validation_latency_ms = $5,
+		    cooldown_until = CASE WHEN $3 THEN NULL ELSE cooldown_until END,
+		    cooldown_reason = CASE WHEN $3 THEN NULL ELSE cooldown_reason END
+		WHERE id = $1
+	`, keyID, v.CheckedAt, v.Valid, validationErr, v.LatencyMs)
+	return err
+}
@@ -0,0 +1,62 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"modelgate/internal/domain"
+)
+
+func TestValidateCredentialsListModels(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/tags" {
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"models":[{"name":"llama3.2"},{"name":"nomic-embed-text"}]}`))
+	}))
+	defer srv.Close()
+
+	v := ValidateCredentials(context.Background(), domain.ProviderOllama, &domain.ProviderConfig{BaseURL: srv.URL})
+	if !v.Valid || v.Method != ValidationMethodListModels || v.ModelCount != 2 || v.Error != nil {
+		t.Fatalf("unexpected result %+v", v)
+	}
+	if !slices.Equal(v.Capabilities, []string{CapabilityChat, CapabilityEmbeddings, CapabilityTools}) {
+		t.Fatalf("unexpected capabilities %v", v.Capabilities)
+	}
+}
+
+func TestValidateCredentialsCompletion(t *testing.T) {
+	var body struct {
+		MaxTokens int `json:"max_tokens"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat/completions" {
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":{"message":"No auth credentials found","code":401}}`))
+	}))
+	defer srv.Close()
+
+	v := ValidateCredentials(context.Background(), domain.ProviderOpenRouter, &domain.ProviderConfig{APIKey: "sk-or-bad", BaseURL: srv.URL})
+	if v.Valid || v.Method != ValidationMethodCompletion || v.Model != validationProbes[domain.ProviderOpenRouter] {
+		t.Fatalf("unexpected result %+v", v)
+	}
+	if v.Error == nil || v.Error.Code != domain.ErrorCodeInvalidAPIKey {
+		t.Fatalf("expected an invalid key error, got %+v", v.Error)
+	}
+	if body.MaxTokens != 1 {
+		t.Fatalf("expected a 1-token completion, got max_tokens %d", body.MaxTokens)
+	}
+
+	// A missing key fails before any call is made
+	v = ValidateCredentials(context.Background(), domain.ProviderAnthropic, &domain.ProviderConfig{})
+	if v.Valid || v.Error == nil || v.LatencyMs != 0 {
+		t.Fatalf("expected a configuration error, got %+v", v)
+	}
+}
@@ -95,23 +95,8 @@ func (c *MistralClient) ChatStream(ctx context.Context, req *domain.ChatRequest)
 		defer close(events)
 
 		url := mistralAPIURL + "/chat/completions"
-		messages := c.buildMessages(req)
-
-		body := map[string]any{
-			"model":    req.Model,
-			"messages": messages,
-			"stream":   true,
-		}
-
-		if req.Temperature != nil {
-			body["temperature"] = *req.Temperature
-		}
-		if req.MaxTokens != nil {
-			body["max_tokens"] = *req.MaxTokens
-		}
-		if len(req.Tools) > 0 {
-			body["tools"] = c.convertTools(req.Tools)
-		}
+		body := c.buildBody(req)
+		body["stream"] = true
 
 		jsonBody, _ := json.Marshal(body)
 		httpReq, err := http.NewRequestWithContext(ctx, "POST", url, strings.NewReader(string(jsonBody)))
@@ -129,10 +114,11 @@ func (c *MistralClient) ChatStream(ctx context.Context, req *domain.ChatRequest)
 			return
 		}
 		defer resp.Body.Close()
+		observeQuota(ctx, resp.Header)
 
 		if resp.StatusCode != http.StatusOK {
 			bodyBytes, _ := io.ReadAll(resp.Body)
-			events <- domain.TextChunk{Content: fmt.Sprintf("Mistral error: %s", string(bodyBytes))}
+			events <- domain.ErrorEvent{Err: NewHTTPError(c.Provider(), resp.StatusCode, bodyBytes)}
 			events <- domain.FinishEvent{Reason: domain.FinishReasonError}
 			return
 		}
@@ -146,22 +132,7 @@ func (c *MistralClient) ChatStream(ctx context.Context, req *domain.ChatRequest)
 // ChatComplete performs non-streaming chat completion
 func (c *MistralClient) ChatComplete(ctx context.Context, req *domain.ChatRequest) (*domain.ChatResponse, error) {
 	url := mistralAPIURL + "/chat/completions"
-	messages := c.buildMessages(req)
-
-	body := map[string]any{
-		"model":    req.Model,
-		"messages": messages,
-	}
-
-	if req.Temperature != nil {
-		body["temperature"] = *req.Temperature
-	}
-	if req.MaxTokens != nil {
-		body["max_tokens"] = *req.MaxTokens
-	}
-	if len(req.Tools) > 0 {
-		body["tools"] = c.convertTools(req.Tools)
-	}
+	body := c.buildBody(req)
 
 	jsonBody, _ := json.Marshal(body)
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, strings.NewReader(string(jsonBody)))
@@ -177,10 +148,11 @@ func (c *MistralClient) ChatComplete(ctx context.Context, req *domain.ChatReques
 		return nil, err
 	}
 	defer resp.Body.Close()
+	observeQuota(ctx, resp.Header)
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("Mistral API error: %s", string(bodyBytes))
+		return nil, NewHTTPError(c.Provider(), resp.StatusCode, bodyBytes)
 	}
 
 	var result struct {
@@ -221,7 +193,7 @@ func (c *MistralClient) ChatComplete(ctx context.Context, req *domain.ChatReques
 
 	if len(result.Choices) > 0 {
 		response.Content = result.Choices[0].Message.Content
-		response.FinishReason = domain.FinishReason(result.Choices[0].FinishReason)
+		response.FinishReason = mistralFinishReason(result.Choices[0].FinishReason)
 
 		for _, tc := range result.Choices[0].Message.ToolCalls {
 			var args map[string]any
@@ -267,10 +239,11 @@ func (c *MistralClient) Embed(ctx context.Context, model string, texts []string,
 		return nil, 0, err
 	}
 	defer resp.Body.Close()
+	observeQuota(ctx, resp.Header)
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, 0, fmt.Errorf("Mistral API error: %s", string(bodyBytes))
+		return nil, 0, NewHTTPError(c.Provider(), resp.StatusCode, bodyBytes)
 	}
 
 	var result struct {
@@ -390,8 +363,41 @@ func (c *MistralClient) ListModels(ctx context.Context) ([]domain.ModelInfo, err
 
 // Helper methods
 
+// buildBody builds the request body of the chat completions endpoint
+func (c *MistralClient) buildBody(req *domain.ChatRequest) map[string]any {
+	body := map[string]any{
+		"model":    req.Model,
+		"messages": c.buildMessages(req),
+	}
+
+	if req.Temperature != nil {
+		body["temperature"] = *req.Temperature
+	}
+	if req.TopP != nil {
+		body["top_p"] = *req.TopP
+	}
+	if req.MaxTokens != nil {
+		body["max_tokens"] = *req.MaxTokens
+	}
+	if len(req.Tools) > 0 {
+		body["tools"] = c.convertTools(req.Tools)
+		if tc := openAIToolChoice(req.ToolChoice); tc != nil {
+			body["tool_choice"] = tc
+		}
+		if req.ParallelToolCalls != nil {
+			body["parallel_tool_calls"] = *req.ParallelToolCalls
+		}
+	}
+	if rf := openAIResponseFormat(req.ResponseFormat); rf != nil {
+		body["response_format"] = rf
+	}
+
+	return body
+}
+
 func (c *MistralClient) buildMessages(req *domain.ChatRequest) []map[string]any {
 	messages := make([]map[string]any, 0)
+	toolNames := make(map[string]string) // Tool call ID -> function name, for tool results
 
 	if req.SystemPrompt != "" {
 		messages = append(messages, map[string]any{
@@ -403,7 +409,9 @@ func (c *MistralClient) buildMessages(req *domain.ChatRequest) []map[string]any
 	for _, msg := range req.Messages {
 		m := map[string]any{"role": msg.Role}
 
-		if len(msg.Content) == 1 && msg.Content[0].Type == "text" {
+		if len(msg.Content) == 0 {
+			m["content"] = ""
+		} else if len(msg.Content) == 1 && msg.Content[0].Type == "text" {
 			m["content"] = msg.Content[0].Text
 		} else {
 			content := make([]map[string]any, 0)
@@ -430,6 +438,7 @@ func (c *MistralClient) buildMessages(req *domain.ChatRequest) []map[string]any
 			toolCalls := make([]map[string]any, len(msg.ToolCalls))
 			for i, tc := range msg.ToolCalls {
 				args, _ := json.Marshal(tc.Function.Arguments)
+				toolNames[tc.ID] = tc.Function.Name
 				toolCalls[i] = map[string]any{
 					"id":   tc.ID,
 					"type": "function",
@@ -444,6 +453,9 @@ func (c *MistralClient) buildMessages(req *domain.ChatRequest) []map[string]any
 
 		if msg.ToolCallID != "" {
 			m["tool_call_id"] = msg.ToolCallID
+			if name := toolNames[msg.ToolCallID]; name != "" {
+				m["name"] = name
+			}
 		}
 
 		messages = append(messages, m)
@@ -452,6 +464,20 @@ func (c *MistralClient) buildMessages(req *domain.ChatRequest) []map[string]any
 	return messages
 }
 
+// mistralFinishReason maps a Mistral finish reason
+func mistralFinishReason(reason string) domain.FinishReason {
+	switch reason {
+	case "tool_calls":
+		return domain.FinishReasonToolCalls
+	case "length", "model_length":
+		return domain.FinishReasonLength
+	case "error":
+		return domain.FinishReasonError
+	default:
+		return domain.FinishReasonStop
+	}
+}
+
 func (c *MistralClient) convertTools(tools []domain.Tool) []map[string]any {
 	result := make([]map[string]any, len(tools))
 	for i, tool := range tools {
@@ -469,7 +495,18 @@ func (c *MistralClient) convertTools(tools []domain.Tool) []map[string]any {
 
 func (c *MistralClient) processSSEStream(body io.Reader, events chan<- domain.StreamEvent) {
 	scanner := bufio.NewScanner(body)
-	var inputTokens, outputTokens int32
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	var usage domain.UsageEvent
+	var toolCalls toolCallStream
+	callKeys := make(map[string]int) // Tool call ID -> key in toolCalls
+
+	// finish sends the tool calls and usage once the stream ends; Mistral
+	// reports usage in the chunk carrying the finish reason
+	finish := func(reason domain.FinishReason) {
+		toolCalls.flush(events)
+		events <- usage
+		events <- domain.FinishEvent{Reason: reason}
+	}
 
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -479,12 +516,7 @@ func (c *MistralClient) processSSEStream(body io.Reader, events chan<- domain.St
 
 		data := strings.TrimPrefix(line, "data: ")
 		if data == "[DONE]" {
-			events <- domain.UsageEvent{
-				PromptTokens:     inputTokens,
-				CompletionTokens: outputTokens,
-				TotalTokens:      inputTokens + outputTokens,
-			}
-			events <- domain.FinishEvent{Reason: domain.FinishReasonStop}
+			finish(domain.FinishReasonStop)
 			return
 		}
 
@@ -503,9 +535,10 @@ func (c *MistralClient) processSSEStream(body io.Reader, events chan<- domain.St
 				} `json:"delta"`
 				FinishReason string `json:"finish_reason"`
 			} `json:"choices"`
-			Usage struct {
+			Usage *struct {
 				PromptTokens     int32 `json:"prompt_tokens"`
 				CompletionTokens int32 `json:"completion_tokens"`
+				TotalTokens      int32 `json:"total_tokens"`
 			} `json:"usage"`
 		}
 
@@ -513,9 +546,12 @@ func (c *MistralClient) processSSEStream(body io.Reader, events chan<- domain.St
 			continue
 		}
 
-		if chunk.Usage.PromptTokens > 0 {
-			inputTokens = chunk.Usage.PromptTokens
-			outputTokens = chunk.Usage.CompletionTokens
+		if chunk.Usage != nil {
+			usage = domain.UsageEvent{
+				PromptTokens:     chunk.Usage.PromptTokens,
+				CompletionTokens: chunk.Usage.CompletionTokens,
+				TotalTokens:      chunk.Usage.TotalTokens,
+			}
 		}
 
 		if len(chunk.Choices) > 0 {
@@ -524,29 +560,21 @@ func (c *MistralClient) processSSEStream(body io.Reader, events chan<- domain.St
 				events <- domain.TextChunk{Content: delta.Content}
 			}
 
+			// Parallel calls usually arrive whole, sometimes all at index 0;
+			// their IDs tell them apart
 			for _, tc := range delta.ToolCalls {
+				key := tc.Index
 				if tc.ID != "" {
-					var args map[string]any
-					json.Unmarshal([]byte(tc.Function.Arguments), &args)
-					events <- domain.ToolCallEvent{
-						ToolCall: domain.ToolCall{
-							ID:   tc.ID,
-							Type: "function",
-							Function: domain.FunctionCall{
-								Name:      tc.Function.Name,
-								Arguments: args,
-							},
-						},
+					if _, ok := callKeys[tc.ID]; !ok {
+						callKeys[tc.ID] = len(callKeys)
 					}
+					key = callKeys[tc.ID]
 				}
+				toolCalls.add(events, key, tc.ID, tc.Function.Name, tc.Function.Arguments)
 			}
 
 			if chunk.Choices[0].FinishReason != "" {
-				if chunk.Choices[0].FinishReason == "tool_calls" {
-					events <- domain.FinishEvent{Reason: domain.FinishReasonToolCalls}
-				} else {
-					events <- domain.FinishEvent{Reason: domain.FinishReasonStop}
-				}
+				finish(mistralFinishReason(chunk.Choices[0].FinishReason))
 				return
 			}
 		}
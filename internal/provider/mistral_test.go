@@ -0,0 +1,61 @@
+package provider
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"modelgate/internal/domain"
+)
+
+func TestMistralParallelToolCallStream(t *testing.T) {
+	stream := strings.Join([]string{
+		`data: {"choices":[{"index":0,"delta":{"role":"assistant","content":""}}]}`,
+		`data: {"choices":[{"index":0,"delta":{"tool_calls":[{"id":"a1","index":0,"function":{"name":"get_weather","arguments":"{\"city\": \"Paris\"}"}}]}}]}`,
+		`data: {"choices":[{"index":0,"delta":{"tool_calls":[{"id":"b2","index":0,"function":{"name":"get_weather","arguments":"{\"city\": \"Rome\"}"}}]},"finish_reason":"tool_calls"}],"usage":{"prompt_tokens":90,"completion_tokens":30,"total_tokens":120}}`,
+		`data: [DONE]`,
+	}, "\n\n") + "\n\n"
+	events := collect(func(events chan<- domain.StreamEvent) {
+		(&MistralClient{}).processSSEStream(strings.NewReader(stream), events)
+	})
+
+	call := func(id, city string) domain.ToolCallEvent {
+		return domain.ToolCallEvent{Streamed: true, ToolCall: domain.ToolCall{ID: id, Type: "function",
+			Function: domain.FunctionCall{Name: "get_weather", Arguments: map[string]any{"city": city}}}}
+	}
+	want := []domain.StreamEvent{
+		domain.ToolCallDelta{Index: 0, ID: "a1", Name: "get_weather", Delta: `{"city": "Paris"}`},
+		domain.ToolCallDelta{Index: 1, ID: "b2", Name: "get_weather", Delta: `{"city": "Rome"}`},
+		call("a1", "Paris"),
+		call("b2", "Rome"),
+		domain.UsageEvent{PromptTokens: 90, CompletionTokens: 30, TotalTokens: 120},
+		domain.FinishEvent{Reason: domain.FinishReasonToolCalls},
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Fatalf("expected %+v, got %+v", want, events)
+	}
+}
+
+func TestMistralRequestBody(t *testing.T) {
+	parallel := false
+	req := &domain.ChatRequest{
+		Model:             "mistral-large-latest",
+		Tools:             []domain.Tool{{Type: "function", Function: domain.FunctionDefinition{Name: "get_weather"}}},
+		ToolChoice:        &domain.ToolChoice{Mode: "required", Function: "get_weather"},
+		ParallelToolCalls: &parallel,
+		Messages: []domain.Message{
+			{Role: "assistant", ToolCalls: []domain.ToolCall{{ID: "a1", Function: domain.FunctionCall{Name: "get_weather"}}}},
+			{Role: "tool", ToolCallID: "a1", Content: []domain.ContentBlock{{Type: "text", Text: "sunny"}}},
+		},
+	}
+	body := (&MistralClient{}).buildBody(req)
+
+	wantChoice := map[string]any{"type": "function", "function": map[string]any{"name": "get_weather"}}
+	if !reflect.DeepEqual(body["tool_choice"], wantChoice) || body["parallel_tool_calls"] != false {
+		t.Fatalf("unexpected tool options %v, %v", body["tool_choice"], body["parallel_tool_calls"])
+	}
+	messages := body["messages"].([]map[string]any)
+	if messages[0]["content"] != "" || messages[1]["name"] != "get_weather" {
+		t.Fatalf("unexpected messages %v", messages)
+	}
+}
@@ -155,6 +155,26 @@ func (s *ModelCacheService) buildCacheFromModels(models []*postgres.AvailableMod
 				shortName := strings.TrimPrefix(model.ModelID, "cohere/")
 				cache[shortName] = nativeID
 			}
+		case domain.ProviderOpenRouter:
+			if strings.HasPrefix(model.ModelID, "openrouter/") {
+				shortName := strings.TrimPrefix(model.ModelID, "openrouter/")
+				cache[shortName] = nativeID
+			}
+		case domain.ProviderXAI:
+			if strings.HasPrefix(model.ModelID, "xai/") {
+				shortName := strings.TrimPrefix(model.ModelID, "xai/")
+				cache[shortName] = nativeID
+			}
+		case domain.ProviderDeepSeek:
+			if strings.HasPrefix(model.ModelID, "deepseek/") {
+				shortName := strings.TrimPrefix(model.ModelID, "deepseek/")
+				cache[shortName] = nativeID
+			}
+		case domain.ProviderFireworks:
+			if strings.HasPrefix(model.ModelID, "fireworks/") {
+				shortName := strings.TrimPrefix(model.ModelID, "fireworks/")
+				cache[shortName] = nativeID
+			}
 		case domain.ProviderOllama:
 			if strings.HasPrefix(model.ModelID, "ollama/") {
 				shortName := strings.TrimPrefix(model.ModelID, "ollama/")
@@ -105,9 +105,8 @@ func (c *OllamaClient) ChatStream(ctx context.Context, req *domain.ChatRequest)
 
 		if resp.StatusCode != http.StatusOK {
 			bodyBytes, _ := io.ReadAll(resp.Body)
-			eventChan <- domain.PolicyViolationEvent{
-				Message: fmt.Sprintf("API error: %s - %s", resp.Status, string(bodyBytes)),
-			}
+			eventChan <- domain.ErrorEvent{Err: NewHTTPError(c.Provider(), resp.StatusCode, bodyBytes)}
+			eventChan <- domain.FinishEvent{Reason: domain.FinishReasonError}
 			return
 		}
 
@@ -143,7 +142,7 @@ func (c *OllamaClient) ChatComplete(ctx context.Context, req *domain.ChatRequest
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error: %s - %s", resp.Status, string(bodyBytes))
+		return nil, NewHTTPError(c.Provider(), resp.StatusCode, bodyBytes)
 	}
 
 	var result struct {
@@ -231,7 +230,7 @@ func (c *OllamaClient) Embed(ctx context.Context, model string, texts []string,
 
 		if resp.StatusCode != http.StatusOK {
 			bodyBytes, _ := io.ReadAll(resp.Body)
-			return nil, 0, fmt.Errorf("API error: %s - %s", resp.Status, string(bodyBytes))
+			return nil, 0, NewHTTPError(c.Provider(), resp.StatusCode, bodyBytes)
 		}
 
 		var result struct {
@@ -387,11 +386,18 @@ func (c *OllamaClient) buildRequest(req *domain.ChatRequest) map[string]any {
 		ollamaReq["tools"] = tools
 	}
 
+	if format := ollamaFormat(req.ResponseFormat); format != nil {
+		ollamaReq["format"] = format
+	}
+
 	// Options
 	options := map[string]any{}
 	if req.Temperature != nil {
 		options["temperature"] = *req.Temperature
 	}
+	if req.TopP != nil {
+		options["top_p"] = *req.TopP
+	}
 	if req.MaxTokens != nil {
 		options["num_predict"] = *req.MaxTokens
 	}
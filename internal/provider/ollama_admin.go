@@ -0,0 +1,150 @@
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// OllamaLocalModel is a model installed on the Ollama server (/api/tags)
+type OllamaLocalModel struct {
+	Name       string    `json:"name"`
+	Digest     string    `json:"digest"`
+	Size       int64     `json:"size"`
+	ModifiedAt time.Time `json:"modified_at"`
+	Details    struct {
+		Family            string `json:"family"`
+		ParameterSize     string `json:"parameter_size"`
+		QuantizationLevel string `json:"quantization_level"`
+	} `json:"details"`
+}
+
+// OllamaRunningModel is a model currently loaded into memory (/api/ps)
+type OllamaRunningModel struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`      // Total memory used
+	SizeVRAM  int64     `json:"size_vram"` // Portion held in GPU memory
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// OllamaPullProgress is one progress update streamed by /api/pull
+type OllamaPullProgress struct {
+	Status    string `json:"status"`
+	Digest    string `json:"digest,omitempty"`
+	Total     int64  `json:"total,omitempty"`
+	Completed int64  `json:"completed,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ListLocalModels returns the models installed on the Ollama server
+func (c *OllamaClient) ListLocalModels(ctx context.Context) ([]OllamaLocalModel, error) {
+	var result struct {
+		Models []OllamaLocalModel `json:"models"`
+	}
+	if err := c.getJSON(ctx, "/api/tags", &result); err != nil {
+		return nil, err
+	}
+	return result.Models, nil
+}
+
+// RunningModels returns the models currently loaded into memory
+func (c *OllamaClient) RunningModels(ctx context.Context) ([]OllamaRunningModel, error) {
+	var result struct {
+		Models []OllamaRunningModel `json:"models"`
+	}
+	if err := c.getJSON(ctx, "/api/ps", &result); err != nil {
+		return nil, err
+	}
+	return result.Models, nil
+}
+
+// PullModel downloads a model from the Ollama library, calling progress for
+// every update. It returns when the pull finishes or fails.
+func (c *OllamaClient) PullModel(ctx context.Context, name string, progress func(OllamaPullProgress)) error {
+	body, _ := json.Marshal(map[string]any{"model": name, "stream": true})
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/pull", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	// Pulls can take far longer than the chat request timeout
+	client := *c.httpClient
+	client.Timeout = 0
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("ollama pull failed: %s - %s", resp.Status, string(bodyBytes))
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var update OllamaPullProgress
+		if err := json.Unmarshal(scanner.Bytes(), &update); err != nil {
+			continue
+		}
+		if update.Error != "" {
+			return fmt.Errorf("ollama pull failed: %s", update.Error)
+		}
+		if progress != nil {
+			progress(update)
+		}
+		if update.Status == "success" {
+			return nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return fmt.Errorf("ollama pull ended without success")
+}
+
+// DeleteModel removes an installed model from the Ollama server
+func (c *OllamaClient) DeleteModel(ctx context.Context, name string) error {
+	body, _ := json.Marshal(map[string]string{"model": name})
+	httpReq, err := http.NewRequestWithContext(ctx, "DELETE", c.baseURL+"/api/delete", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("model %s is not installed", name)
+	}
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("ollama delete failed: %s - %s", resp.Status, string(bodyBytes))
+	}
+	return nil
+}
+
+func (c *OllamaClient) getJSON(ctx context.Context, path string, out any) error {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("ollama API error: %s - %s", resp.Status, string(bodyBytes))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
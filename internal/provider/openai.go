@@ -116,12 +116,12 @@ func (c *OpenAIClient) ChatStream(ctx context.Context, req *domain.ChatRequest)
 			return
 		}
 		defer resp.Body.Close()
+		observeQuota(ctx, resp.Header)
 
 		if resp.StatusCode != http.StatusOK {
 			bodyBytes, _ := io.ReadAll(resp.Body)
-			eventChan <- domain.PolicyViolationEvent{
-				Message: fmt.Sprintf("API error: %s - %s", resp.Status, string(bodyBytes)),
-			}
+			eventChan <- domain.ErrorEvent{Err: NewHTTPError(c.Provider(), resp.StatusCode, bodyBytes)}
+			eventChan <- domain.FinishEvent{Reason: domain.FinishReasonError}
 			return
 		}
 
@@ -155,12 +155,18 @@ func (c *OpenAIClient) ChatComplete(ctx context.Context, req *domain.ChatRequest
 		return nil, err
 	}
 	defer resp.Body.Close()
+	observeQuota(ctx, resp.Header)
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error: %s - %s", resp.Status, string(bodyBytes))
+		return nil, NewHTTPError(c.Provider(), resp.StatusCode, bodyBytes)
 	}
 
+	return decodeOpenAIChatResponse(resp.Body, req.Model)
+}
+
+// decodeOpenAIChatResponse decodes a chat.completion body into a ChatResponse
+func decodeOpenAIChatResponse(r io.Reader, model string) (*domain.ChatResponse, error) {
 	var result struct {
 		ID      string `json:"id"`
 		Choices []struct {
@@ -185,12 +191,12 @@ func (c *OpenAIClient) ChatComplete(ctx context.Context, req *domain.ChatRequest
 		} `json:"usage"`
 	}
 
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+	if err := json.NewDecoder(r).Decode(&result); err != nil {
 		return nil, err
 	}
 
 	response := &domain.ChatResponse{
-		Model: req.Model,
+		Model: model,
 		Usage: &domain.UsageEvent{
 			PromptTokens:     result.Usage.PromptTokens,
 			CompletionTokens: result.Usage.CompletionTokens,
@@ -262,10 +268,11 @@ func (c *OpenAIClient) Embed(ctx context.Context, model string, texts []string,
 		return nil, 0, err
 	}
 	defer resp.Body.Close()
+	observeQuota(ctx, resp.Header)
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, 0, fmt.Errorf("API error: %s - %s", resp.Status, string(bodyBytes))
+		return nil, 0, NewHTTPError(c.Provider(), resp.StatusCode, bodyBytes)
 	}
 
 	var result struct {
@@ -323,6 +330,7 @@ func (c *OpenAIClient) ListModels(ctx context.Context) ([]domain.ModelInfo, erro
 		return nil, err
 	}
 	defer resp.Body.Close()
+	observeQuota(ctx, resp.Header)
 
 	var result struct {
 		Data []struct {
@@ -365,6 +373,9 @@ func (c *OpenAIClient) buildRequest(req *domain.ChatRequest) map[string]any {
 	if req.Temperature != nil {
 		openaiReq["temperature"] = *req.Temperature
 	}
+	if req.TopP != nil {
+		openaiReq["top_p"] = *req.TopP
+	}
 
 	// Build messages
 	var messages []map[string]any
@@ -400,6 +411,8 @@ func (c *OpenAIClient) buildRequest(req *domain.ChatRequest) map[string]any {
 							"url": c.ImageURL,
 						},
 					})
+				case "file":
+					content = append(content, openAIFilePart(c))
 				}
 			}
 			if len(content) > 0 {
@@ -460,6 +473,13 @@ func (c *OpenAIClient) buildRequest(req *domain.ChatRequest) map[string]any {
 			})
 		}
 		openaiReq["tools"] = tools
+		if tc := openAIToolChoice(req.ToolChoice); tc != nil {
+			openaiReq["tool_choice"] = tc
+		}
+	}
+
+	if rf := openAIResponseFormat(req.ResponseFormat); rf != nil {
+		openaiReq["response_format"] = rf
 	}
 
 	return openaiReq
@@ -471,6 +491,7 @@ func (c *OpenAIClient) parseSSEStream(body io.Reader, eventChan chan<- domain.St
 	var lineBuffer strings.Builder
 	finishSent := false            // Track if we've already sent FinishEvent
 	var pendingFinishReason string // Buffer finish reason until usage arrives
+	var toolCalls toolCallStream
 
 	for {
 		n, err := body.Read(buf)
@@ -509,7 +530,7 @@ func (c *OpenAIClient) parseSSEStream(body io.Reader, eventChan chan<- domain.St
 						}
 						return
 					}
-					c.parseChunk(data, eventChan, &finishSent, &pendingFinishReason)
+					c.parseChunk(data, eventChan, &finishSent, &pendingFinishReason, &toolCalls)
 				}
 			}
 		}
@@ -524,7 +545,7 @@ func (c *OpenAIClient) parseSSEStream(body io.Reader, eventChan chan<- domain.St
 }
 
 // parseChunk parses a JSON chunk from the stream
-func (c *OpenAIClient) parseChunk(data string, eventChan chan<- domain.StreamEvent, finishSent *bool, pendingFinishReason *string) {
+func (c *OpenAIClient) parseChunk(data string, eventChan chan<- domain.StreamEvent, finishSent *bool, pendingFinishReason *string, toolCalls *toolCallStream) {
 	var chunk struct {
 		Choices []struct {
 			Delta struct {
@@ -585,17 +606,16 @@ func (c *OpenAIClient) parseChunk(data string, eventChan chan<- domain.StreamEve
 		}
 
 		for _, tc := range choice.Delta.ToolCalls {
-			if tc.Function.Arguments != "" {
-				eventChan <- domain.ToolCallDelta{
-					ID:    tc.ID,
-					Delta: tc.Function.Arguments,
-				}
-			}
+			toolCalls.add(eventChan, tc.Index, tc.ID, tc.Function.Name, tc.Function.Arguments)
 		}
 
-		// Buffer finish reason instead of sending immediately
-		if choice.FinishReason != "" && !*finishSent {
-			*pendingFinishReason = choice.FinishReason
+		// Tool calls are complete once the choice finishes. Buffer the finish
+		// reason instead of sending it immediately.
+		if choice.FinishReason != "" {
+			toolCalls.flush(eventChan)
+			if !*finishSent {
+				*pendingFinishReason = choice.FinishReason
+			}
 		}
 	}
 }
@@ -692,6 +712,7 @@ func (c *OpenAIClient) GenerateResponse(ctx context.Context, req *domain.Respons
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
+	observeQuota(ctx, resp.Header)
 
 	// Read response body for parsing
 	bodyBytes, err := io.ReadAll(resp.Body)
@@ -700,7 +721,7 @@ func (c *OpenAIClient) GenerateResponse(ctx context.Context, req *domain.Respons
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("OpenAI API error %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, NewHTTPError(c.Provider(), resp.StatusCode, bodyBytes)
 	}
 
 	// Log raw response for debugging
@@ -0,0 +1,262 @@
+// Package provider implements LLM provider clients.
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+
+	"modelgate/internal/domain"
+)
+
+// openAIBatchDiscount is OpenAI's Batch API price multiplier (50% off)
+const openAIBatchDiscount = 0.5
+
+// SubmitBatch uploads the items as a JSONL file and creates an OpenAI batch (implements BatchCapable)
+func (c *OpenAIClient) SubmitBatch(ctx context.Context, items []domain.BatchItem) (string, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, item := range items {
+		line := map[string]any{
+			"custom_id": item.CustomID,
+			"method":    "POST",
+			"url":       "/v1/chat/completions",
+			"body":      c.buildRequest(item.Request),
+		}
+		if err := enc.Encode(line); err != nil {
+			return "", fmt.Errorf("encode batch item %s: %w", item.CustomID, err)
+		}
+	}
+
+	fileID, err := c.uploadFile(ctx, "batch", "batch.jsonl", &buf)
+	if err != nil {
+		return "", err
+	}
+
+	var batch struct {
+		ID string `json:"id"`
+	}
+	err = c.doJSON(ctx, "POST", "/batches", map[string]any{
+		"input_file_id":     fileID,
+		"endpoint":          "/v1/chat/completions",
+		"completion_window": "24h",
+	}, &batch)
+	if err != nil {
+		return "", fmt.Errorf("create batch: %w", err)
+	}
+	return batch.ID, nil
+}
+
+// GetBatchStatus polls an OpenAI batch (implements BatchCapable)
+func (c *OpenAIClient) GetBatchStatus(ctx context.Context, providerBatchID string) (*domain.ProviderBatchStatus, error) {
+	batch, err := c.getBatch(ctx, providerBatchID)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.ProviderBatchStatus{
+		// OpenAI batch statuses are the same values ModelGate uses
+		Status: domain.BatchStatus(batch.Status),
+		RequestCounts: domain.BatchCounts{
+			Total:     batch.RequestCounts.Total,
+			Completed: batch.RequestCounts.Completed,
+			Failed:    batch.RequestCounts.Failed,
+		},
+	}, nil
+}
+
+// GetBatchResults downloads the output and error files of a finished batch (implements BatchCapable)
+func (c *OpenAIClient) GetBatchResults(ctx context.Context, providerBatchID string) ([]domain.BatchItemResult, error) {
+	batch, err := c.getBatch(ctx, providerBatchID)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []domain.BatchItemResult
+	for _, fileID := range []string{batch.OutputFileID, batch.ErrorFileID} {
+		if fileID == "" {
+			continue
+		}
+		fileResults, err := c.readBatchResultFile(ctx, fileID)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, fileResults...)
+	}
+	return results, nil
+}
+
+// CancelBatch cancels an OpenAI batch (implements BatchCapable)
+func (c *OpenAIClient) CancelBatch(ctx context.Context, providerBatchID string) error {
+	return c.doJSON(ctx, "POST", "/batches/"+providerBatchID+"/cancel", nil, nil)
+}
+
+// BatchDiscount returns the Batch API price multiplier (implements BatchCapable)
+func (c *OpenAIClient) BatchDiscount() float64 {
+	return openAIBatchDiscount
+}
+
+type openAIBatch struct {
+	ID            string `json:"id"`
+	Status        string `json:"status"`
+	OutputFileID  string `json:"output_file_id"`
+	ErrorFileID   string `json:"error_file_id"`
+	RequestCounts struct {
+		Total     int `json:"total"`
+		Completed int `json:"completed"`
+		Failed    int `json:"failed"`
+	} `json:"request_counts"`
+}
+
+func (c *OpenAIClient) getBatch(ctx context.Context, providerBatchID string) (*openAIBatch, error) {
+	var batch openAIBatch
+	if err := c.doJSON(ctx, "GET", "/batches/"+providerBatchID, nil, &batch); err != nil {
+		return nil, fmt.Errorf("get batch: %w", err)
+	}
+	return &batch, nil
+}
+
+// uploadFile uploads content to the files API with the given purpose and
+// returns the file ID. The multipart body is streamed, so large files are not
+// held in memory.
+func (c *OpenAIClient) uploadFile(ctx context.Context, purpose, filename string, content io.Reader) (string, error) {
+	body, bodyWriter := io.Pipe()
+	mw := multipart.NewWriter(bodyWriter)
+	go func() {
+		err := mw.WriteField("purpose", purpose)
+		if err == nil {
+			var part io.Writer
+			if part, err = mw.CreateFormFile("file", filename); err == nil {
+				_, err = io.Copy(part, content)
+			}
+		}
+		if err == nil {
+			err = mw.Close()
+		}
+		bodyWriter.CloseWithError(err)
+	}()
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/files", body)
+	if err != nil {
+		body.Close()
+		return "", err
+	}
+	httpReq.Header.Set("Content-Type", mw.FormDataContentType())
+	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("upload %s file: %s - %s", purpose, resp.Status, string(bodyBytes))
+	}
+
+	var file struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&file); err != nil {
+		return "", err
+	}
+	return file.ID, nil
+}
+
+// readBatchResultFile downloads and parses a batch output or error file
+func (c *OpenAIClient) readBatchResultFile(ctx context.Context, fileID string) ([]domain.BatchItemResult, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/files/"+fileID+"/content", nil)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("download batch results: %s - %s", resp.Status, string(bodyBytes))
+	}
+
+	var results []domain.BatchItemResult
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var line struct {
+			CustomID string `json:"custom_id"`
+			Response *struct {
+				StatusCode int             `json:"status_code"`
+				Body       json.RawMessage `json:"body"`
+			} `json:"response"`
+			Error *struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			continue
+		}
+
+		result := domain.BatchItemResult{CustomID: line.CustomID}
+		switch {
+		case line.Error != nil:
+			result.Error = line.Error.Message
+		case line.Response != nil && line.Response.StatusCode == http.StatusOK:
+			result.StatusCode = line.Response.StatusCode
+			result.Response, err = decodeOpenAIChatResponse(bytes.NewReader(line.Response.Body), "")
+			if err != nil {
+				result.Error = fmt.Sprintf("invalid response body: %v", err)
+			}
+		case line.Response != nil:
+			result.StatusCode = line.Response.StatusCode
+			result.Error = string(line.Response.Body)
+		}
+		results = append(results, result)
+	}
+	return results, scanner.Err()
+}
+
+// doJSON sends a JSON request to the OpenAI API and decodes the JSON response into out (if non-nil)
+func (c *OpenAIClient) doJSON(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("API error: %s - %s", resp.Status, string(bodyBytes))
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,50 @@
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"strings"
+
+	"modelgate/internal/domain"
+)
+
+// UploadFile uploads a file for use in chat requests (implements FileCapable)
+func (c *OpenAIClient) UploadFile(ctx context.Context, filename, mimeType string, size int64, content io.Reader) (string, error) {
+	return c.uploadFile(ctx, "user_data", filename, content)
+}
+
+// DeleteFile deletes an uploaded file (implements FileCapable)
+func (c *OpenAIClient) DeleteFile(ctx context.Context, providerFileID string) error {
+	return c.doJSON(ctx, "DELETE", "/files/"+providerFileID, nil, nil)
+}
+
+// openAIFilePart converts a file block to an OpenAI chat content part. Images
+// are sent as image parts, since file parts only accept documents.
+func openAIFilePart(block domain.ContentBlock) map[string]any {
+	if block.ProviderFileID != "" {
+		return map[string]any{
+			"type": "file",
+			"file": map[string]any{"file_id": block.ProviderFileID},
+		}
+	}
+	if strings.HasPrefix(block.MediaType, "image/") {
+		return map[string]any{
+			"type":      "image_url",
+			"image_url": map[string]any{"url": dataURL(block.MediaType, block.FileData)},
+		}
+	}
+	file := map[string]any{"file_data": dataURL(block.MediaType, block.FileData)}
+	if block.Filename != "" {
+		file["filename"] = block.Filename
+	}
+	return map[string]any{"type": "file", "file": file}
+}
+
+// dataURL encodes content as a base64 data URL
+func dataURL(mediaType string, data []byte) string {
+	if mediaType == "" {
+		mediaType = "application/octet-stream"
+	}
+	return "data:" + mediaType + ";base64," + base64.StdEncoding.EncodeToString(data)
+}
@@ -0,0 +1,69 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"modelgate/internal/domain"
+)
+
+// UploadTrainingFile uploads a fine-tuning file (implements FineTuneCapable)
+func (c *OpenAIClient) UploadTrainingFile(ctx context.Context, filename string, content io.Reader) (string, error) {
+	return c.uploadFile(ctx, "fine-tune", filename, content)
+}
+
+// CreateFineTuneJob starts an OpenAI fine-tuning job (implements FineTuneCapable)
+func (c *OpenAIClient) CreateFineTuneJob(ctx context.Context, req domain.FineTuneRequest) (string, error) {
+	body := map[string]any{
+		"model":         c.resolveModelID(req.Model),
+		"training_file": req.TrainingFile,
+	}
+	if req.ValidationFile != "" {
+		body["validation_file"] = req.ValidationFile
+	}
+	if len(req.Hyperparameters) > 0 {
+		body["hyperparameters"] = req.Hyperparameters
+	}
+	if req.Suffix != "" {
+		body["suffix"] = req.Suffix
+	}
+
+	var job struct {
+		ID string `json:"id"`
+	}
+	if err := c.doJSON(ctx, "POST", "/fine_tuning/jobs", body, &job); err != nil {
+		return "", fmt.Errorf("create fine-tuning job: %w", err)
+	}
+	return job.ID, nil
+}
+
+// GetFineTuneJob polls an OpenAI fine-tuning job (implements FineTuneCapable)
+func (c *OpenAIClient) GetFineTuneJob(ctx context.Context, providerJobID string) (*domain.ProviderFineTuneJob, error) {
+	var job struct {
+		Status         string `json:"status"`
+		FineTunedModel string `json:"fine_tuned_model"`
+		TrainedTokens  int64  `json:"trained_tokens"`
+		Error          *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := c.doJSON(ctx, "GET", "/fine_tuning/jobs/"+providerJobID, nil, &job); err != nil {
+		return nil, fmt.Errorf("get fine-tuning job: %w", err)
+	}
+	result := &domain.ProviderFineTuneJob{
+		// OpenAI job statuses are the same values ModelGate uses
+		Status:         domain.FineTuneStatus(job.Status),
+		FineTunedModel: job.FineTunedModel,
+		TrainedTokens:  job.TrainedTokens,
+	}
+	if job.Error != nil {
+		result.Error = job.Error.Message
+	}
+	return result, nil
+}
+
+// CancelFineTuneJob cancels an OpenAI fine-tuning job (implements FineTuneCapable)
+func (c *OpenAIClient) CancelFineTuneJob(ctx context.Context, providerJobID string) error {
+	return c.doJSON(ctx, "POST", "/fine_tuning/jobs/"+providerJobID+"/cancel", nil, nil)
+}
@@ -0,0 +1,565 @@
+// Package provider implements LLM provider clients.
+package provider
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"modelgate/internal/domain"
+)
+
+const (
+	openRouterAPIURL = "https://openrouter.ai/api/v1"
+
+	// Attribution headers OpenRouter uses for app rankings
+	openRouterDefaultReferer = "https://github.com/mazori-ai/modelgate"
+	openRouterDefaultTitle   = "ModelGate"
+)
+
+// openRouterVendors maps direct providers to the vendor prefix OpenRouter
+// lists their models under
+var openRouterVendors = map[domain.Provider]string{
+	domain.ProviderOpenAI:    "openai",
+	domain.ProviderAnthropic: "anthropic",
+	domain.ProviderGemini:    "google",
+	domain.ProviderMistral:   "mistralai",
+	domain.ProviderCohere:    "cohere",
+	domain.ProviderXAI:       "x-ai",
+	domain.ProviderDeepSeek:  "deepseek",
+}
+
+// OpenRouterModel returns the OpenRouter model serving a direct provider's
+// model, e.g. "openrouter/google/gemini-2.0-flash" for "gemini/gemini-2.0-flash",
+// so requests can fail over to OpenRouter. It returns false for providers
+// whose models OpenRouter doesn't carry under the same name.
+func OpenRouterModel(primary domain.Provider, model string) (string, bool) {
+	vendor, ok := openRouterVendors[primary]
+	if !ok {
+		return "", false
+	}
+	return "openrouter/" + vendor + "/" + ExtractModelID(model), true
+}
+
+// OpenRouterConfig contains OpenRouter-specific settings
+type OpenRouterConfig struct {
+	APIKey             string
+	BaseURL            string // Optional override of the API URL
+	HTTPReferer        string // Sent as HTTP-Referer
+	XTitle             string // Sent as X-Title
+	ConnectionSettings domain.ConnectionSettings
+}
+
+// OpenRouterClient implements the LLMClient interface for OpenRouter.
+// A single OpenRouter key gives access to models from many upstream vendors,
+// addressed as "openrouter/<vendor>/<model>" (e.g. "openrouter/openai/gpt-4o").
+type OpenRouterClient struct {
+	apiKey      string
+	baseURL     string
+	httpReferer string
+	xTitle      string
+	httpClient  *http.Client
+	modelCache  map[string]string // Cache of model aliases to native model IDs
+}
+
+// NewOpenRouterClient creates a new OpenRouter client
+func NewOpenRouterClient(cfg OpenRouterConfig) (*OpenRouterClient, error) {
+	if cfg.APIKey == "" {
+		return nil, fmt.Errorf("OpenRouter API key is required")
+	}
+
+	connSettings := cfg.ConnectionSettings
+	if connSettings.MaxConnections == 0 {
+		connSettings = domain.DefaultConnectionSettings()
+	}
+
+	baseURL := strings.TrimSuffix(cfg.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = openRouterAPIURL
+	}
+	referer := cfg.HTTPReferer
+	if referer == "" {
+		referer = openRouterDefaultReferer
+	}
+	title := cfg.XTitle
+	if title == "" {
+		title = openRouterDefaultTitle
+	}
+
+	return &OpenRouterClient{
+		apiKey:      cfg.APIKey,
+		baseURL:     baseURL,
+		httpReferer: referer,
+		xTitle:      title,
+		httpClient:  BuildHTTPClient(connSettings),
+		modelCache:  make(map[string]string),
+	}, nil
+}
+
+// SetModelCache sets the model cache (implements ModelCacheable)
+func (c *OpenRouterClient) SetModelCache(cache map[string]string) {
+	c.modelCache = cache
+}
+
+// GetModelCache returns the model cache (implements ModelCacheable)
+func (c *OpenRouterClient) GetModelCache() map[string]string {
+	return c.modelCache
+}
+
+// resolveModelID resolves a model ID using the cache if available.
+// "openrouter/openai/gpt-4o" becomes "openai/gpt-4o".
+func (c *OpenRouterClient) resolveModelID(model string) string {
+	if c.modelCache != nil {
+		if nativeID, ok := c.modelCache[model]; ok {
+			return strings.TrimPrefix(nativeID, "openrouter/")
+		}
+	}
+	return strings.TrimPrefix(model, "openrouter/")
+}
+
+// Provider returns the provider type
+func (c *OpenRouterClient) Provider() domain.Provider {
+	return domain.ProviderOpenRouter
+}
+
+// SupportsModel checks if a model is supported
+func (c *OpenRouterClient) SupportsModel(model string) bool {
+	// OpenRouter model IDs are always "<vendor>/<model>"
+	return strings.Contains(c.resolveModelID(model), "/")
+}
+
+// ChatStream performs streaming chat completion
+func (c *OpenRouterClient) ChatStream(ctx context.Context, req *domain.ChatRequest) (<-chan domain.StreamEvent, error) {
+	events := make(chan domain.StreamEvent, 100)
+
+	go func() {
+		defer close(events)
+
+		body := c.buildRequest(req)
+		body["stream"] = true
+		// Ask for a final usage chunk so streamed requests are billed correctly
+		body["usage"] = map[string]any{"include": true}
+
+		httpReq, err := c.newRequest(ctx, "POST", "/chat/completions", body)
+		if err != nil {
+			events <- domain.FinishEvent{Reason: domain.FinishReasonError}
+			return
+		}
+
+		resp, err := c.httpClient.Do(httpReq)
+		if err != nil {
+			events <- domain.FinishEvent{Reason: domain.FinishReasonError}
+			return
+		}
+		defer resp.Body.Close()
+		observeQuota(ctx, resp.Header)
+
+		if resp.StatusCode != http.StatusOK {
+			bodyBytes, _ := io.ReadAll(resp.Body)
+			events <- domain.ErrorEvent{Err: NewHTTPError(c.Provider(), resp.StatusCode, bodyBytes)}
+			events <- domain.FinishEvent{Reason: domain.FinishReasonError}
+			return
+		}
+
+		c.processSSEStream(resp.Body, events)
+	}()
+
+	return events, nil
+}
+
+// ChatComplete performs non-streaming chat completion
+func (c *OpenRouterClient) ChatComplete(ctx context.Context, req *domain.ChatRequest) (*domain.ChatResponse, error) {
+	httpReq, err := c.newRequest(ctx, "POST", "/chat/completions", c.buildRequest(req))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	observeQuota(ctx, resp.Header)
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, NewHTTPError(c.Provider(), resp.StatusCode, bodyBytes)
+	}
+
+	var result struct {
+		Choices []struct {
+			Message struct {
+				Content   string `json:"content"`
+				ToolCalls []struct {
+					ID       string `json:"id"`
+					Type     string `json:"type"`
+					Function struct {
+						Name      string `json:"name"`
+						Arguments string `json:"arguments"`
+					} `json:"function"`
+				} `json:"tool_calls"`
+			} `json:"message"`
+			FinishReason string `json:"finish_reason"`
+		} `json:"choices"`
+		Usage struct {
+			PromptTokens     int32   `json:"prompt_tokens"`
+			CompletionTokens int32   `json:"completion_tokens"`
+			TotalTokens      int32   `json:"total_tokens"`
+			Cost             float64 `json:"cost"`
+		} `json:"usage"`
+		Model string `json:"model"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	response := &domain.ChatResponse{
+		Model: result.Model,
+		Usage: &domain.UsageEvent{
+			PromptTokens:     result.Usage.PromptTokens,
+			CompletionTokens: result.Usage.CompletionTokens,
+			TotalTokens:      result.Usage.TotalTokens,
+			CostUSD:          result.Usage.Cost,
+		},
+	}
+
+	if len(result.Choices) > 0 {
+		response.Content = result.Choices[0].Message.Content
+		response.FinishReason = domain.FinishReason(result.Choices[0].FinishReason)
+
+		for _, tc := range result.Choices[0].Message.ToolCalls {
+			var args map[string]any
+			json.Unmarshal([]byte(tc.Function.Arguments), &args)
+			response.ToolCalls = append(response.ToolCalls, domain.ToolCall{
+				ID:   tc.ID,
+				Type: tc.Type,
+				Function: domain.FunctionCall{
+					Name:      tc.Function.Name,
+					Arguments: args,
+				},
+			})
+		}
+	}
+
+	return response, nil
+}
+
+// Embed generates embeddings (not offered by OpenRouter)
+func (c *OpenRouterClient) Embed(ctx context.Context, model string, texts []string, dimensions *int32) ([][]float32, int64, error) {
+	return nil, 0, fmt.Errorf("OpenRouter does not support embeddings")
+}
+
+// CountTokens counts tokens in a request
+func (c *OpenRouterClient) CountTokens(ctx context.Context, req *domain.ChatRequest) (int32, error) {
+	var total int32
+	for _, msg := range req.Messages {
+		for _, block := range msg.Content {
+			if block.Type == "text" {
+				total += int32(len(block.Text) / 4)
+			}
+		}
+	}
+	return total, nil
+}
+
+// ListModels fetches the OpenRouter model catalog including per-model pricing.
+// Pricing is returned in USD per token and converted to USD per 1M tokens, so
+// refreshing OpenRouter models imports prices into available_models.
+func (c *OpenRouterClient) ListModels(ctx context.Context) ([]domain.ModelInfo, error) {
+	httpReq, err := c.newRequest(ctx, "GET", "/models", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	observeQuota(ctx, resp.Header)
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, NewHTTPError(c.Provider(), resp.StatusCode, bodyBytes)
+	}
+
+	var result struct {
+		Data []struct {
+			ID            string `json:"id"`
+			Name          string `json:"name"`
+			ContextLength uint32 `json:"context_length"`
+			Pricing       struct {
+				Prompt     string `json:"prompt"`
+				Completion string `json:"completion"`
+			} `json:"pricing"`
+			TopProvider struct {
+				MaxCompletionTokens uint32 `json:"max_completion_tokens"`
+			} `json:"top_provider"`
+			SupportedParameters []string `json:"supported_parameters"`
+		} `json:"data"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	models := make([]domain.ModelInfo, 0, len(result.Data))
+	for _, m := range result.Data {
+		info := domain.ModelInfo{
+			ID:              "openrouter/" + m.ID,
+			Name:            m.Name,
+			Provider:        domain.ProviderOpenRouter,
+			ContextLimit:    m.ContextLength,
+			OutputLimit:     m.TopProvider.MaxCompletionTokens,
+			InputCostPer1M:  perTokenToPerMillion(m.Pricing.Prompt),
+			OutputCostPer1M: perTokenToPerMillion(m.Pricing.Completion),
+			Enabled:         true,
+			NativeModelID:   m.ID,
+		}
+		for _, p := range m.SupportedParameters {
+			switch p {
+			case "tools":
+				info.SupportsTools = true
+			case "reasoning", "include_reasoning":
+				info.SupportsReasoning = true
+			}
+		}
+		models = append(models, info)
+	}
+
+	sort.Slice(models, func(i, j int) bool { return models[i].ID < models[j].ID })
+	return models, nil
+}
+
+// perTokenToPerMillion converts an OpenRouter per-token price string to USD per 1M tokens.
+// Negative prices mark variable-priced routers (e.g. openrouter/auto) and are treated as unknown.
+func perTokenToPerMillion(price string) float64 {
+	v, err := strconv.ParseFloat(price, 64)
+	if err != nil || v < 0 {
+		return 0
+	}
+	return v * 1_000_000
+}
+
+// Helper methods
+
+// newRequest builds an authenticated request with OpenRouter attribution headers
+func (c *OpenRouterClient) newRequest(ctx context.Context, method, path string, body map[string]any) (*http.Request, error) {
+	var reader io.Reader
+	if body != nil {
+		jsonBody, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = strings.NewReader(string(jsonBody))
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	httpReq.Header.Set("HTTP-Referer", c.httpReferer)
+	httpReq.Header.Set("X-Title", c.xTitle)
+	return httpReq, nil
+}
+
+func (c *OpenRouterClient) buildRequest(req *domain.ChatRequest) map[string]any {
+	body := map[string]any{
+		"model":    c.resolveModelID(req.Model),
+		"messages": c.buildMessages(req),
+	}
+
+	if req.Temperature != nil {
+		body["temperature"] = *req.Temperature
+	}
+	if req.TopP != nil {
+		body["top_p"] = *req.TopP
+	}
+	if req.MaxTokens != nil {
+		body["max_tokens"] = *req.MaxTokens
+	}
+	if len(req.Tools) > 0 {
+		body["tools"] = c.convertTools(req.Tools)
+		if tc := openAIToolChoice(req.ToolChoice); tc != nil {
+			body["tool_choice"] = tc
+		}
+	}
+	if rf := openAIResponseFormat(req.ResponseFormat); rf != nil {
+		body["response_format"] = rf
+	}
+
+	return body
+}
+
+func (c *OpenRouterClient) buildMessages(req *domain.ChatRequest) []map[string]any {
+	messages := make([]map[string]any, 0)
+
+	if req.SystemPrompt != "" {
+		messages = append(messages, map[string]any{
+			"role":    "system",
+			"content": req.SystemPrompt,
+		})
+	}
+
+	for _, msg := range req.Messages {
+		m := map[string]any{"role": msg.Role}
+
+		var textContent strings.Builder
+		for _, block := range msg.Content {
+			if block.Type == "text" {
+				textContent.WriteString(block.Text)
+			}
+		}
+		m["content"] = textContent.String()
+
+		if len(msg.ToolCalls) > 0 {
+			toolCalls := make([]map[string]any, len(msg.ToolCalls))
+			for i, tc := range msg.ToolCalls {
+				args, _ := json.Marshal(tc.Function.Arguments)
+				toolCalls[i] = map[string]any{
+					"id":   tc.ID,
+					"type": "function",
+					"function": map[string]any{
+						"name":      tc.Function.Name,
+						"arguments": string(args),
+					},
+				}
+			}
+			m["tool_calls"] = toolCalls
+		}
+
+		if msg.ToolCallID != "" {
+			m["tool_call_id"] = msg.ToolCallID
+		}
+
+		messages = append(messages, m)
+	}
+
+	return messages
+}
+
+func (c *OpenRouterClient) convertTools(tools []domain.Tool) []map[string]any {
+	result := make([]map[string]any, len(tools))
+	for i, tool := range tools {
+		result[i] = map[string]any{
+			"type": "function",
+			"function": map[string]any{
+				"name":        tool.Function.Name,
+				"description": tool.Function.Description,
+				"parameters":  tool.Function.Parameters,
+			},
+		}
+	}
+	return result
+}
+
+// processSSEStream reads OpenAI-style SSE chunks. Tool call arguments arrive in
+// fragments keyed by index; they are passed on as deltas and the complete
+// calls are emitted at the end.
+func (c *OpenRouterClient) processSSEStream(body io.Reader, events chan<- domain.StreamEvent) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var inputTokens, outputTokens int32
+	var cost float64
+	var finishReason domain.FinishReason
+	var toolCalls toolCallStream
+
+	finish := func() {
+		toolCalls.flush(events)
+		events <- domain.UsageEvent{
+			PromptTokens:     inputTokens,
+			CompletionTokens: outputTokens,
+			TotalTokens:      inputTokens + outputTokens,
+			CostUSD:          cost,
+		}
+		if finishReason == "" {
+			finishReason = domain.FinishReasonStop
+		}
+		events <- domain.FinishEvent{Reason: finishReason}
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		// OpenRouter sends ": OPENROUTER PROCESSING" comments as keep-alives
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+
+		data := strings.TrimPrefix(line, "data: ")
+		if data == "[DONE]" {
+			finish()
+			return
+		}
+
+		var chunk struct {
+			Choices []struct {
+				Delta struct {
+					Content   string `json:"content"`
+					ToolCalls []struct {
+						Index    int    `json:"index"`
+						ID       string `json:"id"`
+						Function struct {
+							Name      string `json:"name"`
+							Arguments string `json:"arguments"`
+						} `json:"function"`
+					} `json:"tool_calls"`
+				} `json:"delta"`
+				FinishReason string `json:"finish_reason"`
+			} `json:"choices"`
+			Usage *struct {
+				PromptTokens     int32   `json:"prompt_tokens"`
+				CompletionTokens int32   `json:"completion_tokens"`
+				Cost             float64 `json:"cost"`
+			} `json:"usage"`
+		}
+
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			continue
+		}
+
+		if chunk.Usage != nil {
+			inputTokens = chunk.Usage.PromptTokens
+			outputTokens = chunk.Usage.CompletionTokens
+			cost = chunk.Usage.Cost
+		}
+
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+
+		delta := chunk.Choices[0].Delta
+		if delta.Content != "" {
+			events <- domain.TextChunk{Content: delta.Content}
+		}
+
+		for _, tc := range delta.ToolCalls {
+			toolCalls.add(events, tc.Index, tc.ID, tc.Function.Name, tc.Function.Arguments)
+		}
+
+		// Usage arrives in a separate chunk after the finish reason, so keep reading until [DONE]
+		switch chunk.Choices[0].FinishReason {
+		case "":
+		case "tool_calls":
+			finishReason = domain.FinishReasonToolCalls
+		case "length":
+			finishReason = domain.FinishReasonLength
+		default:
+			finishReason = domain.FinishReasonStop
+		}
+	}
+
+	finish()
+}
@@ -0,0 +1,134 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"modelgate/internal/domain"
+)
+
+func TestOpenRouterRequest(t *testing.T) {
+	c := &OpenRouterClient{}
+	body := c.buildRequest(&domain.ChatRequest{
+		Model:        "openrouter/anthropic/claude-3.5-sonnet",
+		SystemPrompt: "Be brief.",
+		Messages: []domain.Message{
+			{Role: "user", Content: []domain.ContentBlock{{Type: "text", Text: "Weather in Paris?"}}},
+			{Role: "assistant", ToolCalls: []domain.ToolCall{{ID: "call_1", Function: domain.FunctionCall{Name: "get_weather", Arguments: map[string]any{"city": "Paris"}}}}},
+			{Role: "tool", ToolCallID: "call_1", Content: []domain.ContentBlock{{Type: "text", Text: "sunny"}}},
+		},
+		Tools:      []domain.Tool{{Type: "function", Function: domain.FunctionDefinition{Name: "get_weather"}}},
+		ToolChoice: &domain.ToolChoice{Mode: "required", Function: "get_weather"},
+	})
+	if body["model"] != "anthropic/claude-3.5-sonnet" {
+		t.Errorf("model = %v", body["model"])
+	}
+	if want := map[string]any{"type": "function", "function": map[string]any{"name": "get_weather"}}; !reflect.DeepEqual(body["tool_choice"], want) {
+		t.Errorf("tool_choice = %v", body["tool_choice"])
+	}
+	messages := body["messages"].([]map[string]any)
+	if len(messages) != 4 || messages[0]["role"] != "system" || messages[3]["tool_call_id"] != "call_1" {
+		t.Fatalf("unexpected messages %v", messages)
+	}
+	calls := messages[2]["tool_calls"].([]map[string]any)
+	if fn := calls[0]["function"].(map[string]any); fn["name"] != "get_weather" || fn["arguments"] != `{"city":"Paris"}` {
+		t.Errorf("unexpected tool call %v", calls[0])
+	}
+}
+
+func TestOpenRouterStream(t *testing.T) {
+	stream := strings.Join([]string{
+		`: OPENROUTER PROCESSING`,
+		`data: {"choices":[{"delta":{"content":"Checking."}}]}`,
+		`data: {"choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_1","function":{"name":"get_weather","arguments":"{\"city\":"}}]}}]}`,
+		`data: {"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"Paris\"}"}}]},"finish_reason":"tool_calls"}]}`,
+		`data: {"choices":[],"usage":{"prompt_tokens":40,"completion_tokens":10,"cost":0.0012}}`,
+		`data: [DONE]`,
+	}, "\n\n") + "\n\n"
+	events := collect(func(events chan<- domain.StreamEvent) {
+		(&OpenRouterClient{}).processSSEStream(strings.NewReader(stream), events)
+	})
+
+	var text string
+	var call *domain.ToolCall
+	var usage *domain.UsageEvent
+	var finish domain.FinishReason
+	for _, event := range events {
+		switch e := event.(type) {
+		case domain.TextChunk:
+			text += e.Content
+		case domain.ToolCallEvent:
+			call = &e.ToolCall
+		case domain.UsageEvent:
+			usage = &e
+		case domain.FinishEvent:
+			finish = e.Reason
+		}
+	}
+	if text != "Checking." {
+		t.Errorf("text = %q", text)
+	}
+	if call == nil || call.ID != "call_1" || call.Function.Arguments["city"] != "Paris" {
+		t.Errorf("unexpected tool call %+v", call)
+	}
+	if usage == nil || usage.TotalTokens != 50 || usage.CostUSD != 0.0012 {
+		t.Errorf("unexpected usage %+v", usage)
+	}
+	if finish != domain.FinishReasonToolCalls {
+		t.Errorf("finish reason = %s", finish)
+	}
+}
+
+func TestOpenRouterComplete(t *testing.T) {
+	var headers http.Header
+	var sent map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header
+		json.NewDecoder(r.Body).Decode(&sent)
+		w.Write([]byte(`{"model":"openai/gpt-4o","choices":[{"message":{"tool_calls":[{"id":"call_1","type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"Paris\"}"}}]},"finish_reason":"tool_calls"}],"usage":{"prompt_tokens":30,"completion_tokens":5,"total_tokens":35,"cost":0.0004}}`))
+	}))
+	defer server.Close()
+
+	c, err := NewOpenRouterClient(OpenRouterConfig{APIKey: "sk-or", BaseURL: server.URL, XTitle: "Acme"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.ChatComplete(context.Background(), &domain.ChatRequest{
+		Model:    "openrouter/openai/gpt-4o",
+		Messages: []domain.Message{{Role: "user", Content: []domain.ContentBlock{{Type: "text", Text: "Weather in Paris?"}}}},
+		Tools:    []domain.Tool{{Type: "function", Function: domain.FunctionDefinition{Name: "get_weather"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if headers.Get("Authorization") != "Bearer sk-or" || headers.Get("X-Title") != "Acme" || headers.Get("HTTP-Referer") != openRouterDefaultReferer {
+		t.Errorf("unexpected headers %v", headers)
+	}
+	if sent["model"] != "openai/gpt-4o" || sent["tools"] == nil {
+		t.Errorf("unexpected request %v", sent)
+	}
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].Function.Arguments["city"] != "Paris" || resp.FinishReason != domain.FinishReasonToolCalls {
+		t.Errorf("unexpected response %+v", resp)
+	}
+	if resp.Usage.TotalTokens != 35 || resp.Usage.CostUSD != 0.0004 {
+		t.Errorf("unexpected usage %+v", resp.Usage)
+	}
+}
+
+func TestOpenRouterModel(t *testing.T) {
+	if model, ok := OpenRouterModel(domain.ProviderGemini, "gemini/gemini-2.0-flash"); !ok || model != "openrouter/google/gemini-2.0-flash" {
+		t.Errorf("gemini model = %q, %v", model, ok)
+	}
+	if model, ok := OpenRouterModel(domain.ProviderXAI, "xai/grok-3"); !ok || model != "openrouter/x-ai/grok-3" {
+		t.Errorf("xai model = %q, %v", model, ok)
+	}
+	// Deployment names of Azure OpenAI aren't OpenRouter models
+	if _, ok := OpenRouterModel(domain.ProviderAzureOpenAI, "azure_openai/prod-gpt4o"); ok {
+		t.Error("expected Azure OpenAI models to have no OpenRouter equivalent")
+	}
+}
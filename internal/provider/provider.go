@@ -4,9 +4,12 @@ package provider
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"reflect"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"modelgate/internal/config"
@@ -26,7 +29,7 @@ func BuildHTTPClient(settings domain.ConnectionSettings) *http.Client {
 
 	return &http.Client{
 		Timeout:   time.Duration(settings.RequestTimeoutSec) * time.Second,
-		Transport: transport,
+		Transport: newPoolTransport(transport, settings),
 	}
 }
 
@@ -42,25 +45,45 @@ const (
 	ProviderMistral     = domain.ProviderMistral
 	ProviderTogether    = domain.ProviderTogether
 	ProviderCohere      = domain.ProviderCohere
+	ProviderOpenRouter  = domain.ProviderOpenRouter
+	ProviderXAI         = domain.ProviderXAI
+	ProviderDeepSeek    = domain.ProviderDeepSeek
+	ProviderHuggingFace = domain.ProviderHuggingFace
+	ProviderFireworks   = domain.ProviderFireworks
+	ProviderCustom      = domain.ProviderCustom
 )
 
 // Manager manages multiple LLM provider clients
 type Manager struct {
-	clients       map[domain.Provider]domain.LLMClient            // Global fallback clients
-	tenantClients map[string]map[domain.Provider]domain.LLMClient // Tenant-specific clients
-	config        *config.Config
+	clients       map[domain.Provider]domain.LLMClient      // Global fallback clients
+	tenantClients map[string]map[clientKey]domain.LLMClient // Tenant-specific clients
+	config        atomic.Pointer[config.Config]
 	modelCache    *ModelCacheService // Centralized model cache for all providers
 	mu            sync.RWMutex
+
+	// Connection pool metrics per provider, see warmup.go
+	pools        map[domain.Provider]*poolStats
+	poolObserver PoolObserver
+	poolMu       sync.Mutex
+}
+
+// clientKey identifies a cached tenant client. Each provider API key gets its
+// own client so key selection applies per request rather than per process,
+// and each regional deployment its own client.
+type clientKey struct {
+	provider domain.Provider
+	apiKeyID string
+	region   string
 }
 
 // NewManager creates a new provider manager
 func NewManager(cfg *config.Config) (*Manager, error) {
 	m := &Manager{
 		clients:       make(map[domain.Provider]domain.LLMClient),
-		tenantClients: make(map[string]map[domain.Provider]domain.LLMClient),
-		config:        cfg,
+		tenantClients: make(map[string]map[clientKey]domain.LLMClient),
 		modelCache:    NewModelCacheService(),
 	}
+	m.config.Store(cfg)
 
 	// NOTE: Provider clients are now loaded per-tenant from the database (provider_configs table)
 	// No global fallback clients are initialized from environment variables
@@ -69,6 +92,31 @@ func NewManager(cfg *config.Config) (*Manager, error) {
 	return m, nil
 }
 
+// SetConfig swaps in a reloaded configuration. When provider settings changed,
+// cached clients are dropped so the next request builds fresh ones; requests
+// already streaming keep the client they hold.
+func (m *Manager) SetConfig(cfg *config.Config) {
+	old := m.config.Swap(cfg)
+	if old != nil && reflect.DeepEqual(old.Providers, cfg.Providers) {
+		return
+	}
+
+	m.mu.Lock()
+	tenants := make([]string, 0, len(m.tenantClients))
+	for tenantID := range m.tenantClients {
+		tenants = append(tenants, tenantID)
+	}
+	m.tenantClients = make(map[string]map[clientKey]domain.LLMClient)
+	m.mu.Unlock()
+
+	if m.modelCache != nil {
+		for _, tenantID := range tenants {
+			m.modelCache.InvalidateTenantCache(tenantID)
+		}
+	}
+	slog.Info("Provider settings changed, cached clients dropped", "tenants", len(tenants))
+}
+
 // GetModelCacheService returns the model cache service
 func (m *Manager) GetModelCacheService() *ModelCacheService {
 	return m.modelCache
@@ -80,12 +128,35 @@ func (m *Manager) GetOrCreateTenantClient(tenantID string, provider domain.Provi
 	defer m.mu.Unlock()
 
 	// Check if we already have a cached tenant client
+	key := clientKey{provider: provider, apiKeyID: providerCfg.APIKeyID, region: providerCfg.ActiveRegion}
 	if tenantClients, ok := m.tenantClients[tenantID]; ok {
-		if client, ok := tenantClients[provider]; ok {
+		if client, ok := tenantClients[key]; ok {
 			return client, nil
 		}
 	}
 
+	client, err := newClient(provider, providerCfg)
+	if err != nil {
+		return nil, err
+	}
+	m.trackPools(provider, client)
+
+	// Apply model cache if client supports it
+	if m.modelCache != nil {
+		m.modelCache.ApplyToClient(tenantID, provider, client)
+	}
+
+	// Cache the client
+	if _, ok := m.tenantClients[tenantID]; !ok {
+		m.tenantClients[tenantID] = make(map[clientKey]domain.LLMClient)
+	}
+	m.tenantClients[tenantID][key] = client
+
+	return client, nil
+}
+
+// newClient creates an uncached client from a tenant's provider config
+func newClient(provider domain.Provider, providerCfg *domain.ProviderConfig) (domain.LLMClient, error) {
 	// Get connection settings from provider config
 	connSettings := providerCfg.ConnectionSettings
 	if connSettings.MaxConnections == 0 {
@@ -176,6 +247,62 @@ func (m *Manager) GetOrCreateTenantClient(tenantID string, provider domain.Provi
 		}
 		client, err = NewCohereClient(providerCfg.APIKey, connSettings)
 
+	case domain.ProviderOpenRouter:
+		if providerCfg.APIKey == "" {
+			return nil, fmt.Errorf("OpenRouter API key not configured for tenant")
+		}
+		client, err = NewOpenRouterClient(OpenRouterConfig{
+			APIKey:             providerCfg.APIKey,
+			BaseURL:            providerCfg.BaseURL,
+			HTTPReferer:        providerCfg.ExtraSettings["http_referer"],
+			XTitle:             providerCfg.ExtraSettings["x_title"],
+			ConnectionSettings: connSettings,
+		})
+
+	case domain.ProviderXAI:
+		if providerCfg.APIKey == "" {
+			return nil, fmt.Errorf("xAI API key not configured for tenant")
+		}
+		client, err = NewXAIClient(XAIConfig{
+			APIKey:             providerCfg.APIKey,
+			BaseURL:            providerCfg.BaseURL,
+			ConnectionSettings: connSettings,
+		})
+
+	case domain.ProviderDeepSeek:
+		if providerCfg.APIKey == "" {
+			return nil, fmt.Errorf("DeepSeek API key not configured for tenant")
+		}
+		client, err = NewDeepSeekClient(DeepSeekConfig{
+			APIKey:             providerCfg.APIKey,
+			BaseURL:            providerCfg.BaseURL,
+			ConnectionSettings: connSettings,
+		})
+
+	case domain.ProviderFireworks:
+		if providerCfg.APIKey == "" {
+			return nil, fmt.Errorf("Fireworks API key not configured for tenant")
+		}
+		client, err = NewFireworksClient(FireworksConfig{
+			APIKey:             providerCfg.APIKey,
+			BaseURL:            providerCfg.BaseURL,
+			ConnectionSettings: connSettings,
+		})
+
+	case domain.ProviderHuggingFace:
+		if providerCfg.APIKey == "" {
+			return nil, fmt.Errorf("Hugging Face access token not configured for tenant")
+		}
+		client, err = NewHuggingFaceClient(HuggingFaceConfig{
+			APIKey:             providerCfg.APIKey,
+			BaseURL:            providerCfg.BaseURL,
+			Endpoints:          providerCfg.HuggingFaceEndpoints,
+			ConnectionSettings: connSettings,
+		})
+
+	case domain.ProviderCustom:
+		client, err = NewCustomClient(providerCfg.APIKey, providerCfg.CustomModels, connSettings)
+
 	default:
 		return nil, fmt.Errorf("unknown provider: %s", provider)
 	}
@@ -183,18 +310,6 @@ func (m *Manager) GetOrCreateTenantClient(tenantID string, provider domain.Provi
 	if err != nil {
 		return nil, fmt.Errorf("failed to create %s client: %w", provider, err)
 	}
-
-	// Apply model cache if client supports it
-	if m.modelCache != nil {
-		m.modelCache.ApplyToClient(tenantID, provider, client)
-	}
-
-	// Cache the client
-	if _, ok := m.tenantClients[tenantID]; !ok {
-		m.tenantClients[tenantID] = make(map[domain.Provider]domain.LLMClient)
-	}
-	m.tenantClients[tenantID][provider] = client
-
 	return client, nil
 }
 
@@ -223,11 +338,12 @@ func (m *Manager) SetProviderModelCache(tenantID string, provider domain.Provide
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	if tenantClients, ok := m.tenantClients[tenantID]; ok {
-		if client, ok := tenantClients[provider]; ok {
-			if cacheable, ok := client.(ModelCacheable); ok {
-				cacheable.SetModelCache(cache)
-			}
+	for key, client := range m.tenantClients[tenantID] {
+		if key.provider != provider {
+			continue
+		}
+		if cacheable, ok := client.(ModelCacheable); ok {
+			cacheable.SetModelCache(cache)
 		}
 	}
 }
@@ -280,7 +396,7 @@ func (m *Manager) GetClient(provider domain.Provider) (domain.LLMClient, error)
 
 // GetClientForModel returns the appropriate client for a model
 func (m *Manager) GetClientForModel(model string) (domain.LLMClient, error) {
-	provider, ok := m.config.GetProviderForModel(model)
+	provider, ok := m.config.Load().GetProviderForModel(model)
 	if !ok {
 		// Try to infer from model name
 		provider = inferProviderFromModel(model)
@@ -312,7 +428,7 @@ func (m *Manager) ListAllModels(ctx context.Context) ([]domain.ModelInfo, error)
 	var allModels []domain.ModelInfo
 
 	// First, add models from config
-	for id, modelCfg := range m.config.Models {
+	for id, modelCfg := range m.config.Load().Models {
 		if modelCfg.Enabled {
 			allModels = append(allModels, modelCfg.ToModelInfo(id))
 		}
@@ -358,6 +474,8 @@ func inferProviderFromModel(model string) domain.Provider {
 		switch parts[0] {
 		case "meta-llama", "mistralai", "Qwen", "deepseek-ai", "togethercomputer":
 			return domain.ProviderTogether
+		case "accounts":
+			return domain.ProviderFireworks
 		}
 	}
 
@@ -373,6 +491,10 @@ func inferProviderFromModel(model string) domain.Provider {
 		return domain.ProviderOpenAI
 	case strings.Contains(modelLower, "anthropic.claude"):
 		return domain.ProviderBedrock
+	case strings.HasPrefix(modelLower, "grok"):
+		return domain.ProviderXAI
+	case strings.HasPrefix(modelLower, "deepseek-"):
+		return domain.ProviderDeepSeek
 	// Groq models
 	case strings.HasPrefix(modelLower, "llama-3.") && strings.Contains(modelLower, "groq"):
 		return domain.ProviderGroq
@@ -0,0 +1,121 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+// QuotaObserver receives the rate limit state a provider reported on a response
+type QuotaObserver func(domain.ProviderQuota)
+
+type quotaObserverKey struct{}
+
+// WithQuotaObserver returns a context whose provider calls report upstream
+// rate limit headers to fn
+func WithQuotaObserver(ctx context.Context, fn QuotaObserver) context.Context {
+	return context.WithValue(ctx, quotaObserverKey{}, fn)
+}
+
+// observeQuota parses rate limit headers from a provider response and hands
+// them to the context's observer, if any
+func observeQuota(ctx context.Context, h http.Header) {
+	fn, ok := ctx.Value(quotaObserverKey{}).(QuotaObserver)
+	if !ok || fn == nil {
+		return
+	}
+	if q, ok := ParseQuotaHeaders(h, time.Now()); ok {
+		fn(q)
+	}
+}
+
+// quotaHeaderSets lists the limit, remaining and reset header names each
+// provider family uses, for requests and tokens
+var quotaHeaderSets = []struct {
+	requests, tokens [3]string
+}{
+	// OpenAI, Azure OpenAI, Groq, Together, xAI, OpenRouter
+	{
+		requests: [3]string{"x-ratelimit-limit-requests", "x-ratelimit-remaining-requests", "x-ratelimit-reset-requests"},
+		tokens:   [3]string{"x-ratelimit-limit-tokens", "x-ratelimit-remaining-tokens", "x-ratelimit-reset-tokens"},
+	},
+	// Anthropic
+	{
+		requests: [3]string{"anthropic-ratelimit-requests-limit", "anthropic-ratelimit-requests-remaining", "anthropic-ratelimit-requests-reset"},
+		tokens:   [3]string{"anthropic-ratelimit-tokens-limit", "anthropic-ratelimit-tokens-remaining", "anthropic-ratelimit-tokens-reset"},
+	},
+	// Mistral reports per-minute token windows only
+	{
+		tokens: [3]string{"x-ratelimit-limit-tokens-minute", "x-ratelimit-remaining-tokens-minute", ""},
+	},
+}
+
+// ParseQuotaHeaders extracts upstream rate limit state from response headers.
+// It returns false when the response carried none.
+func ParseQuotaHeaders(h http.Header, now time.Time) (domain.ProviderQuota, bool) {
+	q := domain.ProviderQuota{ObservedAt: now}
+	for _, set := range quotaHeaderSets {
+		if q.Requests == nil {
+			q.Requests = parseQuotaWindow(h, set.requests, now)
+		}
+		if q.Tokens == nil {
+			q.Tokens = parseQuotaWindow(h, set.tokens, now)
+		}
+	}
+
+	if ms := h.Get("retry-after-ms"); ms != "" {
+		if v, err := strconv.ParseFloat(ms, 64); err == nil && v > 0 {
+			q.RetryAt = now.Add(time.Duration(v * float64(time.Millisecond)))
+		}
+	} else if at := h.Get("retry-after"); at != "" {
+		if secs, err := strconv.Atoi(at); err == nil && secs > 0 {
+			q.RetryAt = now.Add(time.Duration(secs) * time.Second)
+		} else if t, err := http.ParseTime(at); err == nil && t.After(now) {
+			q.RetryAt = t
+		}
+	}
+
+	return q, q.Requests != nil || q.Tokens != nil || !q.RetryAt.IsZero()
+}
+
+// parseQuotaWindow reads one window; names are the limit, remaining and reset headers
+func parseQuotaWindow(h http.Header, names [3]string, now time.Time) *domain.ProviderQuotaWindow {
+	if names[1] == "" {
+		return nil
+	}
+	remaining, err := strconv.Atoi(strings.TrimSpace(h.Get(names[1])))
+	if err != nil {
+		return nil
+	}
+	w := &domain.ProviderQuotaWindow{Remaining: remaining}
+	if limit, err := strconv.Atoi(strings.TrimSpace(h.Get(names[0]))); err == nil {
+		w.Limit = limit
+	}
+	if names[2] != "" {
+		w.ResetAt = parseQuotaReset(h.Get(names[2]), now)
+	}
+	return w
+}
+
+// parseQuotaReset accepts a duration ("6m0s", "20ms"), a number of seconds, or
+// an RFC 3339 timestamp
+func parseQuotaReset(v string, now time.Time) time.Time {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return time.Time{}
+	}
+	if d, err := time.ParseDuration(v); err == nil {
+		return now.Add(d)
+	}
+	if secs, err := strconv.ParseFloat(v, 64); err == nil {
+		return now.Add(time.Duration(secs * float64(time.Second)))
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t
+	}
+	return time.Time{}
+}
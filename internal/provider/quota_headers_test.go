@@ -0,0 +1,88 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+type staticQuotas map[string]domain.ProviderQuota
+
+func (s staticQuotas) KeyQuota(provider, keyID string) (domain.ProviderQuota, bool) {
+	q, ok := s[keyID]
+	return q, ok
+}
+
+func TestParseQuotaHeaders(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	openai := http.Header{}
+	openai.Set("x-ratelimit-limit-requests", "500")
+	openai.Set("x-ratelimit-remaining-requests", "499")
+	openai.Set("x-ratelimit-reset-requests", "120ms")
+	openai.Set("x-ratelimit-limit-tokens", "30000")
+	openai.Set("x-ratelimit-remaining-tokens", "2000")
+	openai.Set("x-ratelimit-reset-tokens", "6m0s")
+	q, ok := ParseQuotaHeaders(openai, now)
+	if !ok || q.Requests == nil || q.Tokens == nil {
+		t.Fatalf("expected both windows, got %+v", q)
+	}
+	if q.Requests.Remaining != 499 || !q.Requests.ResetAt.Equal(now.Add(120*time.Millisecond)) {
+		t.Fatalf("unexpected requests window %+v", q.Requests)
+	}
+	if !q.Tokens.ResetAt.Equal(now.Add(6 * time.Minute)) {
+		t.Fatalf("unexpected tokens reset %v", q.Tokens.ResetAt)
+	}
+	// 2000 of 30000 tokens is above the 5% low-water mark
+	if q.Low(quotaLowWater, now) {
+		t.Fatal("expected the key not to be low")
+	}
+	q.Tokens.Remaining = 900 // Under 5% of 30000
+	if !q.Low(quotaLowWater, now) {
+		t.Fatal("expected the key to be low")
+	}
+	if q.Low(quotaLowWater, now.Add(7*time.Minute)) {
+		t.Fatal("expected the observation to expire once the window resets")
+	}
+
+	anthropic := http.Header{}
+	anthropic.Set("anthropic-ratelimit-requests-limit", "50")
+	anthropic.Set("anthropic-ratelimit-requests-remaining", "0")
+	anthropic.Set("anthropic-ratelimit-requests-reset", now.Add(30*time.Second).Format(time.RFC3339))
+	anthropic.Set("retry-after", "30")
+	q, ok = ParseQuotaHeaders(anthropic, now)
+	if !ok || q.Requests == nil || q.Requests.Remaining != 0 || !q.RetryAt.Equal(now.Add(30*time.Second)) {
+		t.Fatalf("unexpected anthropic quota %+v", q)
+	}
+	if !q.Low(quotaLowWater, now) {
+		t.Fatal("expected an exhausted key to be low")
+	}
+
+	if _, ok := ParseQuotaHeaders(http.Header{}, now); ok {
+		t.Fatal("expected no quota without headers")
+	}
+}
+
+func TestObserveQuotaAndAvoidLowKeys(t *testing.T) {
+	var got []domain.ProviderQuota
+	ctx := WithQuotaObserver(context.Background(), func(q domain.ProviderQuota) { got = append(got, q) })
+	h := http.Header{}
+	h.Set("retry-after", "5")
+	observeQuota(ctx, h)
+	observeQuota(context.Background(), h)
+	if len(got) != 1 {
+		t.Fatalf("expected one observation, got %d", len(got))
+	}
+
+	ks := NewKeySelector(nil)
+	ks.SetQuotaSource(staticQuotas{"low": got[0]})
+	if !ks.isQuotaLow(domain.ProviderOpenAI, &ProviderAPIKey{ID: "low"}) {
+		t.Fatal("expected a key inside retry-after to be avoided")
+	}
+	if ks.isQuotaLow(domain.ProviderOpenAI, &ProviderAPIKey{ID: "other"}) {
+		t.Fatal("expected a key without reports to be usable")
+	}
+}
@@ -0,0 +1,63 @@
+package provider
+
+import "modelgate/internal/domain"
+
+// openAIResponseFormat converts a response format to the OpenAI chat
+// completions response_format object, or nil when the request has none
+func openAIResponseFormat(f *domain.ResponseFormat) map[string]any {
+	if !f.Structured() {
+		return nil
+	}
+	if f.Type == domain.ResponseFormatJSONObject || f.JSONSchema == nil {
+		return map[string]any{"type": domain.ResponseFormatJSONObject}
+	}
+	schema := map[string]any{
+		"name":   f.JSONSchema.Name,
+		"schema": f.JSONSchema.Schema,
+		"strict": f.JSONSchema.Strict,
+	}
+	if f.JSONSchema.Description != "" {
+		schema["description"] = f.JSONSchema.Description
+	}
+	return map[string]any{
+		"type":        domain.ResponseFormatJSONSchema,
+		"json_schema": schema,
+	}
+}
+
+// openAIToolChoice converts a tool choice to the OpenAI chat completions
+// tool_choice field: the mode, or the named-function object when one
+// function is forced. It returns nil when the request has none.
+func openAIToolChoice(tc *domain.ToolChoice) any {
+	if tc == nil || tc.Mode == "" {
+		return nil
+	}
+	if tc.Function != "" {
+		return map[string]any{"type": "function", "function": map[string]any{"name": tc.Function}}
+	}
+	return tc.Mode
+}
+
+// ollamaFormat converts a response format to Ollama's format field: a JSON
+// schema, or "json" for free-form JSON
+func ollamaFormat(f *domain.ResponseFormat) any {
+	if !f.Structured() {
+		return nil
+	}
+	if f.Type == domain.ResponseFormatJSONSchema && f.JSONSchema != nil {
+		return f.JSONSchema.Schema
+	}
+	return "json"
+}
+
+// Providers that enforce response_format natively (implement domain.StructuredOutputCapable)
+
+func (c *OpenAIClient) SupportsStructuredOutput() bool      { return true }
+func (c *AzureOpenAIClient) SupportsStructuredOutput() bool { return true }
+func (c *GroqClient) SupportsStructuredOutput() bool        { return true }
+func (c *TogetherClient) SupportsStructuredOutput() bool    { return true }
+func (c *MistralClient) SupportsStructuredOutput() bool     { return true }
+func (c *XAIClient) SupportsStructuredOutput() bool         { return true }
+func (c *OpenRouterClient) SupportsStructuredOutput() bool  { return true }
+func (c *OllamaClient) SupportsStructuredOutput() bool      { return true }
+func (c *FireworksClient) SupportsStructuredOutput() bool   { return true }
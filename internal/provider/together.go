@@ -92,11 +92,20 @@ func (c *TogetherClient) ChatStream(ctx context.Context, req *domain.ChatRequest
 		if req.Temperature != nil {
 			body["temperature"] = *req.Temperature
 		}
+		if req.TopP != nil {
+			body["top_p"] = *req.TopP
+		}
 		if req.MaxTokens != nil {
 			body["max_tokens"] = *req.MaxTokens
 		}
 		if len(req.Tools) > 0 {
 			body["tools"] = c.convertTools(req.Tools)
+			if tc := openAIToolChoice(req.ToolChoice); tc != nil {
+				body["tool_choice"] = tc
+			}
+		}
+		if rf := openAIResponseFormat(req.ResponseFormat); rf != nil {
+			body["response_format"] = rf
 		}
 
 		jsonBody, _ := json.Marshal(body)
@@ -115,10 +124,11 @@ func (c *TogetherClient) ChatStream(ctx context.Context, req *domain.ChatRequest
 			return
 		}
 		defer resp.Body.Close()
+		observeQuota(ctx, resp.Header)
 
 		if resp.StatusCode != http.StatusOK {
 			bodyBytes, _ := io.ReadAll(resp.Body)
-			events <- domain.TextChunk{Content: fmt.Sprintf("Together AI error: %s", string(bodyBytes))}
+			events <- domain.ErrorEvent{Err: NewHTTPError(c.Provider(), resp.StatusCode, bodyBytes)}
 			events <- domain.FinishEvent{Reason: domain.FinishReasonError}
 			return
 		}
@@ -142,11 +152,20 @@ func (c *TogetherClient) ChatComplete(ctx context.Context, req *domain.ChatReque
 	if req.Temperature != nil {
 		body["temperature"] = *req.Temperature
 	}
+	if req.TopP != nil {
+		body["top_p"] = *req.TopP
+	}
 	if req.MaxTokens != nil {
 		body["max_tokens"] = *req.MaxTokens
 	}
 	if len(req.Tools) > 0 {
 		body["tools"] = c.convertTools(req.Tools)
+		if tc := openAIToolChoice(req.ToolChoice); tc != nil {
+			body["tool_choice"] = tc
+		}
+	}
+	if rf := openAIResponseFormat(req.ResponseFormat); rf != nil {
+		body["response_format"] = rf
 	}
 
 	jsonBody, _ := json.Marshal(body)
@@ -163,10 +182,11 @@ func (c *TogetherClient) ChatComplete(ctx context.Context, req *domain.ChatReque
 		return nil, err
 	}
 	defer resp.Body.Close()
+	observeQuota(ctx, resp.Header)
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("Together AI API error: %s", string(bodyBytes))
+		return nil, NewHTTPError(c.Provider(), resp.StatusCode, bodyBytes)
 	}
 
 	var result struct {
@@ -253,10 +273,11 @@ func (c *TogetherClient) Embed(ctx context.Context, model string, texts []string
 		return nil, 0, err
 	}
 	defer resp.Body.Close()
+	observeQuota(ctx, resp.Header)
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, 0, fmt.Errorf("Together AI API error: %s", string(bodyBytes))
+		return nil, 0, NewHTTPError(c.Provider(), resp.StatusCode, bodyBytes)
 	}
 
 	var result struct {
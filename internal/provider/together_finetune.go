@@ -0,0 +1,168 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+
+	"modelgate/internal/domain"
+)
+
+// UploadTrainingFile uploads a fine-tuning file to Together's files API (implements FineTuneCapable)
+func (c *TogetherClient) UploadTrainingFile(ctx context.Context, filename string, content io.Reader) (string, error) {
+	body, bodyWriter := io.Pipe()
+	mw := multipart.NewWriter(bodyWriter)
+	go func() {
+		err := mw.WriteField("purpose", "fine-tune")
+		if err == nil {
+			err = mw.WriteField("file_name", filename)
+		}
+		if err == nil {
+			var part io.Writer
+			if part, err = mw.CreateFormFile("file", filename); err == nil {
+				_, err = io.Copy(part, content)
+			}
+		}
+		if err == nil {
+			err = mw.Close()
+		}
+		bodyWriter.CloseWithError(err)
+	}()
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", togetherAPIURL+"/files/upload", body)
+	if err != nil {
+		body.Close()
+		return "", err
+	}
+	httpReq.Header.Set("Content-Type", mw.FormDataContentType())
+	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("upload fine-tune file: %s - %s", resp.Status, string(bodyBytes))
+	}
+
+	var file struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&file); err != nil {
+		return "", err
+	}
+	return file.ID, nil
+}
+
+// CreateFineTuneJob starts a Together fine-tuning job (implements FineTuneCapable).
+// Together takes hyperparameters such as n_epochs, batch_size and
+// learning_rate at the top level of the request.
+func (c *TogetherClient) CreateFineTuneJob(ctx context.Context, req domain.FineTuneRequest) (string, error) {
+	body := make(map[string]any, len(req.Hyperparameters)+4)
+	for k, v := range req.Hyperparameters {
+		body[k] = v
+	}
+	body["model"] = c.resolveModelID(req.Model)
+	body["training_file"] = req.TrainingFile
+	if req.ValidationFile != "" {
+		body["validation_file"] = req.ValidationFile
+	}
+	if req.Suffix != "" {
+		body["suffix"] = req.Suffix
+	}
+
+	var job struct {
+		ID string `json:"id"`
+	}
+	if err := c.doJSON(ctx, "POST", "/fine-tunes", body, &job); err != nil {
+		return "", fmt.Errorf("create fine-tuning job: %w", err)
+	}
+	return job.ID, nil
+}
+
+// GetFineTuneJob polls a Together fine-tuning job (implements FineTuneCapable)
+func (c *TogetherClient) GetFineTuneJob(ctx context.Context, providerJobID string) (*domain.ProviderFineTuneJob, error) {
+	var job struct {
+		Status     string `json:"status"`
+		OutputName string `json:"output_name"`
+		TokenCount int64  `json:"token_count"`
+	}
+	if err := c.doJSON(ctx, "GET", "/fine-tunes/"+providerJobID, nil, &job); err != nil {
+		return nil, fmt.Errorf("get fine-tuning job: %w", err)
+	}
+	result := &domain.ProviderFineTuneJob{
+		Status:         togetherFineTuneStatus(job.Status),
+		FineTunedModel: job.OutputName,
+		TrainedTokens:  job.TokenCount,
+	}
+	if result.Status == domain.FineTuneStatusFailed {
+		result.Error = "fine-tuning job failed at Together"
+	}
+	return result, nil
+}
+
+// CancelFineTuneJob cancels a Together fine-tuning job (implements FineTuneCapable)
+func (c *TogetherClient) CancelFineTuneJob(ctx context.Context, providerJobID string) error {
+	return c.doJSON(ctx, "POST", "/fine-tunes/"+providerJobID+"/cancel", nil, nil)
+}
+
+// togetherFineTuneStatus maps Together's job statuses to ModelGate's
+func togetherFineTuneStatus(status string) domain.FineTuneStatus {
+	switch status {
+	case "pending", "queued":
+		return domain.FineTuneStatusQueued
+	case "completed":
+		return domain.FineTuneStatusSucceeded
+	case "error":
+		return domain.FineTuneStatusFailed
+	case "cancelled":
+		return domain.FineTuneStatusCancelled
+	default:
+		// running, compressing, uploading and cancel_requested
+		return domain.FineTuneStatusRunning
+	}
+}
+
+// doJSON sends a JSON request to the Together API and decodes the JSON response into out (if non-nil)
+func (c *TogetherClient) doJSON(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, method, togetherAPIURL+path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("API error: %s - %s", resp.Status, string(bodyBytes))
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,11 @@
+package provider
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestTogetherToolChoice(t *testing.T) {
+	recorder := &bodyRecorder{}
+	checkToolChoiceSent(t, recorder, &TogetherClient{apiKey: "key", httpClient: &http.Client{Transport: recorder}})
+}
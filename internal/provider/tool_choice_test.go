@@ -0,0 +1,106 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"modelgate/internal/domain"
+)
+
+func TestForcedToolChoice(t *testing.T) {
+	req := &domain.ChatRequest{
+		Model:      "model",
+		Messages:   []domain.Message{{Role: "user", Content: []domain.ContentBlock{{Type: "text", Text: "weather?"}}}},
+		Tools:      []domain.Tool{{Type: "function", Function: domain.FunctionDefinition{Name: "get_weather"}}},
+		ToolChoice: &domain.ToolChoice{Mode: "required", Function: "get_weather"},
+	}
+	builders := map[string]func(*domain.ChatRequest) map[string]any{
+		"openai":     (&OpenAIClient{}).buildRequest,
+		"openrouter": (&OpenRouterClient{}).buildRequest,
+		"xai":        (&XAIClient{}).buildRequest,
+		"deepseek":   (&DeepSeekClient{}).buildRequest,
+		"mistral":    (&MistralClient{}).buildBody,
+		"fireworks":  (&FireworksClient{}).buildRequest,
+		"huggingface": func(r *domain.ChatRequest) map[string]any {
+			return (&HuggingFaceClient{}).buildRequest(&hfTarget{model: "m"}, r)
+		},
+		"custom": func(r *domain.ChatRequest) map[string]any {
+			return (&CustomClient{}).buildRequest(&domain.CustomModel{ModelID: "m"}, r)
+		},
+	}
+
+	forced := map[string]any{"type": "function", "function": map[string]any{"name": "get_weather"}}
+	for name, build := range builders {
+		if got := build(req)["tool_choice"]; !reflect.DeepEqual(got, forced) {
+			t.Errorf("%s: tool_choice = %v, want the named function", name, got)
+		}
+	}
+
+	// Modes without a function are sent as is
+	req.ToolChoice = &domain.ToolChoice{Mode: "required"}
+	for name, build := range builders {
+		if got := build(req)["tool_choice"]; got != "required" {
+			t.Errorf("%s: tool_choice = %v, want required", name, got)
+		}
+	}
+}
+
+// bodyRecorder is an http.RoundTripper that records the JSON bodies sent to a
+// provider and answers with an empty completion or stream
+type bodyRecorder struct {
+	bodies []map[string]any
+}
+
+func (b *bodyRecorder) RoundTrip(r *http.Request) (*http.Response, error) {
+	var body map[string]any
+	json.NewDecoder(r.Body).Decode(&body)
+	b.bodies = append(b.bodies, body)
+
+	answer := `{"model":"m","choices":[{"message":{"content":"ok"},"finish_reason":"stop"}],"usage":{}}`
+	if body["stream"] == true {
+		answer = "data: [DONE]\n\n"
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{},
+		Body:       io.NopCloser(strings.NewReader(answer)),
+		Request:    r,
+	}, nil
+}
+
+// checkToolChoiceSent sends a request forcing a tool through the adapter's
+// ChatComplete and ChatStream and checks that both bodies carry tool_choice
+func checkToolChoiceSent(t *testing.T, recorder *bodyRecorder, client domain.LLMClient) {
+	t.Helper()
+	req := &domain.ChatRequest{
+		Model:      "model",
+		Messages:   []domain.Message{{Role: "user", Content: []domain.ContentBlock{{Type: "text", Text: "weather?"}}}},
+		Tools:      []domain.Tool{{Type: "function", Function: domain.FunctionDefinition{Name: "get_weather"}}},
+		ToolChoice: &domain.ToolChoice{Mode: "required", Function: "get_weather"},
+	}
+	ctx := context.Background()
+	if _, err := client.ChatComplete(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	events, err := client.ChatStream(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range events {
+	}
+
+	forced := map[string]any{"type": "function", "function": map[string]any{"name": "get_weather"}}
+	if len(recorder.bodies) != 2 {
+		t.Fatalf("expected a completion and a stream request, got %d", len(recorder.bodies))
+	}
+	for i, body := range recorder.bodies {
+		if !reflect.DeepEqual(body["tool_choice"], forced) {
+			t.Errorf("request %d: tool_choice = %v, want the named function", i, body["tool_choice"])
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package provider
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"modelgate/internal/domain"
+)
+
+// toolCallStream accumulates tool calls streamed in fragments. Each fragment
+// is passed on as a ToolCallDelta right away, and the complete calls are
+// emitted as ToolCallEvents by flush, for consumers that need whole calls.
+// Calls are numbered 0, 1, ... in the order they start, whatever key the
+// provider uses for them (a tool call index, or a content block index).
+type toolCallStream struct {
+	calls []*streamedToolCall
+	byKey map[int]*streamedToolCall
+}
+
+type streamedToolCall struct {
+	index    int
+	id, name string
+	args     strings.Builder
+}
+
+// add records a fragment of the call with the given key. id and name are
+// only taken from the call's first fragment; a call streamed without an ID
+// gets a generated one.
+func (s *toolCallStream) add(events chan<- domain.StreamEvent, key int, id, name, args string) {
+	if s.byKey == nil {
+		s.byKey = make(map[int]*streamedToolCall)
+	}
+	tc, ok := s.byKey[key]
+	if !ok {
+		if id == "" {
+			id = "call_" + strings.ReplaceAll(uuid.NewString(), "-", "")[:24]
+		}
+		tc = &streamedToolCall{index: len(s.calls), id: id, name: name}
+		s.byKey[key] = tc
+		s.calls = append(s.calls, tc)
+		tc.args.WriteString(args)
+		events <- domain.ToolCallDelta{Index: tc.index, ID: tc.id, Name: tc.name, Delta: args}
+		return
+	}
+	if tc.name == "" && name != "" {
+		tc.name = name
+	}
+	if args == "" {
+		return
+	}
+	tc.args.WriteString(args)
+	events <- domain.ToolCallDelta{Index: tc.index, Delta: args}
+}
+
+// flush emits the accumulated calls as ToolCallEvents and resets the stream
+func (s *toolCallStream) flush(events chan<- domain.StreamEvent) {
+	for _, tc := range s.calls {
+		events <- domain.ToolCallEvent{ToolCall: tc.toolCall(), Streamed: true}
+	}
+	s.calls = nil
+	s.byKey = nil
+}
+
+func (tc *streamedToolCall) toolCall() domain.ToolCall {
+	args := map[string]any{}
+	if raw := strings.TrimSpace(tc.args.String()); raw != "" {
+		json.Unmarshal([]byte(raw), &args)
+	}
+	return domain.ToolCall{
+		ID:       tc.id,
+		Type:     "function",
+		Function: domain.FunctionCall{Name: tc.name, Arguments: args},
+	}
+}
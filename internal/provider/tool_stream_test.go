@@ -0,0 +1,76 @@
+package provider
+
+import (
+	"strings"
+	"testing"
+
+	"modelgate/internal/domain"
+)
+
+func collect(parse func(events chan<- domain.StreamEvent)) []domain.StreamEvent {
+	events := make(chan domain.StreamEvent, 100)
+	parse(events)
+	close(events)
+	var out []domain.StreamEvent
+	for e := range events {
+		out = append(out, e)
+	}
+	return out
+}
+
+func TestToolCallStreaming(t *testing.T) {
+	openAIStream := strings.Join([]string{
+		`data: {"choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_a","type":"function","function":{"name":"get_weather","arguments":""}}]}}]}`,
+		`data: {"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"city\":"}}]}}]}`,
+		`data: {"choices":[{"delta":{"tool_calls":[{"index":1,"id":"call_b","type":"function","function":{"name":"get_time","arguments":"{}"}}]}}]}`,
+		`data: {"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"Paris\"}"}}]}}]}`,
+		`data: {"choices":[{"delta":{},"finish_reason":"tool_calls"}]}`,
+		`data: [DONE]`,
+	}, "\n\n") + "\n\n"
+	events := collect(func(events chan<- domain.StreamEvent) {
+		(&OpenAIClient{}).parseSSEStream(strings.NewReader(openAIStream), events)
+	})
+
+	want := []domain.ToolCallDelta{
+		{Index: 0, ID: "call_a", Name: "get_weather"},
+		{Index: 0, Delta: `{"city":`},
+		{Index: 1, ID: "call_b", Name: "get_time", Delta: "{}"},
+		{Index: 0, Delta: `"Paris"}`},
+	}
+	if len(events) != len(want)+3 {
+		t.Fatalf("expected %d deltas, 2 complete calls and a finish, got %+v", len(want), events)
+	}
+	for i, w := range want {
+		if events[i] != w {
+			t.Errorf("delta %d: expected %+v, got %+v", i, w, events[i])
+		}
+	}
+	call, ok := events[4].(domain.ToolCallEvent)
+	if !ok || !call.Streamed || call.ToolCall.ID != "call_a" || call.ToolCall.Function.Arguments["city"] != "Paris" {
+		t.Fatalf("expected the complete first call, got %+v", events[4])
+	}
+	if finish, ok := events[6].(domain.FinishEvent); !ok || finish.Reason != domain.FinishReasonToolCalls {
+		t.Fatalf("expected a tool_calls finish, got %+v", events[6])
+	}
+
+	// Anthropic numbers content blocks, text included; calls are renumbered from 0
+	anthropicChunks := []string{
+		`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Checking."}}`,
+		`{"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_1","name":"get_weather","input":{}}}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"city\": \"Paris\"}"}}`,
+		`{"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":12}}`,
+	}
+	events = collect(func(events chan<- domain.StreamEvent) {
+		var toolCalls toolCallStream
+		for _, chunk := range anthropicChunks {
+			(&AnthropicClient{}).parseChunk(chunk, events, &toolCalls)
+		}
+	})
+	if d, ok := events[1].(domain.ToolCallDelta); !ok || d.Index != 0 || d.ID != "toolu_1" {
+		t.Fatalf("expected the call to start at index 0, got %+v", events[1])
+	}
+	if call, ok := events[4].(domain.ToolCallEvent); !ok || call.ToolCall.Function.Arguments["city"] != "Paris" {
+		t.Fatalf("expected the complete call before the finish, got %+v", events)
+	}
+}
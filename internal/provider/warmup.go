@@ -0,0 +1,407 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+)
+
+// warmTimeout bounds a single warm-up or keep-alive round for one client
+const warmTimeout = 10 * time.Second
+
+// PoolObserver receives the connection pool metrics of each provider, summed
+// over every client of that provider
+type PoolObserver interface {
+	UpdateProviderPool(provider string, active, idle int)
+	RecordProviderPoolWait(provider string)
+}
+
+// poolStats counts the connections and requests of one provider's clients
+type poolStats struct {
+	provider string
+	observer PoolObserver
+	open     atomic.Int64 // Connections dialed and not yet closed
+	active   atomic.Int64 // Requests sent and not yet finished
+}
+
+// report hands the current counts to the observer. Idle connections are the
+// open ones not serving a request; HTTP/2 connections serving several
+// requests at once count as a single busy connection.
+func (s *poolStats) report() {
+	if s.observer == nil {
+		return
+	}
+	active := s.active.Load()
+	idle := s.open.Load() - active
+	if idle < 0 {
+		idle = 0
+	}
+	s.observer.UpdateProviderPool(s.provider, int(active), int(idle))
+}
+
+// poolTransport wraps a provider client's transport to count its connections
+// and in-flight requests. Counting starts once the manager assigns the client
+// to a provider; clients built outside the manager are never counted.
+type poolTransport struct {
+	base     *http.Transport
+	settings domain.ConnectionSettings
+	active   atomic.Int64 // This transport's in-flight requests
+	stats    atomic.Pointer[poolStats]
+}
+
+// newPoolTransport wraps base and dials through a counting dialer
+func newPoolTransport(base *http.Transport, settings domain.ConnectionSettings) *poolTransport {
+	t := &poolTransport{base: base, settings: settings}
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	base.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dialer.DialContext(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		stats := t.stats.Load()
+		if stats == nil {
+			return conn, nil
+		}
+		stats.open.Add(1)
+		stats.report()
+		return &countedConn{Conn: conn, stats: stats}, nil
+	}
+	return t
+}
+
+// RoundTrip implements http.RoundTripper. A request that finds every
+// connection the transport may open busy has to wait for one and is counted
+// as a pool wait.
+func (t *poolTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	stats := t.stats.Load()
+	if stats == nil {
+		return t.base.RoundTrip(req)
+	}
+
+	inFlight := t.active.Add(1)
+	stats.active.Add(1)
+	if limit := t.settings.MaxConnections; limit > 0 && inFlight > int64(limit) && stats.observer != nil {
+		stats.observer.RecordProviderPoolWait(stats.provider)
+	}
+	stats.report()
+
+	var once sync.Once
+	done := func() {
+		once.Do(func() {
+			t.active.Add(-1)
+			stats.active.Add(-1)
+			stats.report()
+		})
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		done()
+		return nil, err
+	}
+	resp.Body = &countedBody{ReadCloser: resp.Body, done: done}
+	return resp, nil
+}
+
+// CloseIdleConnections lets http.Client.CloseIdleConnections reach the
+// wrapped transport
+func (t *poolTransport) CloseIdleConnections() {
+	t.base.CloseIdleConnections()
+}
+
+// countedConn takes a connection off its provider's open count when closed
+type countedConn struct {
+	net.Conn
+	stats *poolStats
+	once  sync.Once
+}
+
+func (c *countedConn) Close() error {
+	c.once.Do(func() {
+		c.stats.open.Add(-1)
+		c.stats.report()
+	})
+	return c.Conn.Close()
+}
+
+// countedBody ends a request's in-flight count once its body is closed
+type countedBody struct {
+	io.ReadCloser
+	done func()
+}
+
+func (b *countedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.done()
+	return err
+}
+
+// poolTarget is an HTTP client of a provider client and a URL on the host it
+// talks to
+type poolTarget struct {
+	client *http.Client
+	url    string
+}
+
+// connectionPool is implemented by clients whose connections can be opened
+// ahead of their first request
+type connectionPool interface {
+	poolTargets() []poolTarget
+}
+
+func (c *AnthropicClient) poolTargets() []poolTarget {
+	return []poolTarget{{c.httpClient, c.baseURL}}
+}
+
+func (c *OpenAIClient) poolTargets() []poolTarget {
+	return []poolTarget{{c.httpClient, c.baseURL}}
+}
+
+func (c *GeminiClient) poolTargets() []poolTarget {
+	return []poolTarget{{c.httpClient, c.baseURL}}
+}
+
+func (c *OllamaClient) poolTargets() []poolTarget {
+	return []poolTarget{{c.httpClient, c.baseURL}}
+}
+
+func (c *AzureOpenAIClient) poolTargets() []poolTarget {
+	return []poolTarget{{c.httpClient, c.endpoint}}
+}
+
+func (c *GroqClient) poolTargets() []poolTarget {
+	return []poolTarget{{c.httpClient, groqAPIURL}}
+}
+
+func (c *MistralClient) poolTargets() []poolTarget {
+	return []poolTarget{{c.httpClient, mistralAPIURL}}
+}
+
+func (c *TogetherClient) poolTargets() []poolTarget {
+	return []poolTarget{{c.httpClient, togetherAPIURL}}
+}
+
+func (c *CohereClient) poolTargets() []poolTarget {
+	return []poolTarget{{c.httpClient, cohereAPIURL}}
+}
+
+func (c *OpenRouterClient) poolTargets() []poolTarget {
+	return []poolTarget{{c.httpClient, c.baseURL}}
+}
+
+func (c *XAIClient) poolTargets() []poolTarget {
+	return []poolTarget{{c.httpClient, c.baseURL}}
+}
+
+func (c *DeepSeekClient) poolTargets() []poolTarget {
+	return []poolTarget{{c.httpClient, c.baseURL}}
+}
+
+func (c *HuggingFaceClient) poolTargets() []poolTarget {
+	targets := []poolTarget{{c.httpClient, c.baseURL}}
+	for _, ep := range c.endpoints {
+		targets = append(targets, poolTarget{c.httpClient, ep.URL})
+	}
+	return targets
+}
+
+func (c *CustomClient) poolTargets() []poolTarget {
+	seen := make(map[string]bool)
+	var targets []poolTarget
+	for _, m := range c.models {
+		if m.BaseURL == "" || seen[m.BaseURL] {
+			continue
+		}
+		seen[m.BaseURL] = true
+		targets = append(targets, poolTarget{c.httpClient, m.BaseURL})
+	}
+	return targets
+}
+
+func (c *BedrockClient) poolTargets() []poolTarget {
+	if c.useSDKStreaming {
+		return []poolTarget{{c.runtimeHTTP, c.getBedrockEndpoint()}}
+	}
+	return []poolTarget{{c.httpClient, c.getBedrockEndpoint()}}
+}
+
+// warmTarget opens up to connections connections to the target's host with
+// concurrent HEAD requests, so DNS, TCP and TLS are done before real traffic
+// arrives. The count is capped at the client's max_idle_connections, since
+// more would be closed again right away, and nothing is opened when the client
+// doesn't keep connections alive. Any HTTP status counts as success.
+func warmTarget(ctx context.Context, target poolTarget, connections int) error {
+	if t, ok := target.client.Transport.(*poolTransport); ok {
+		if !t.settings.EnableKeepAlive {
+			return nil
+		}
+		if t.settings.MaxIdleConnections > 0 {
+			connections = min(connections, t.settings.MaxIdleConnections)
+		}
+	}
+	connections = max(connections, 1)
+
+	errs := make([]error, connections)
+	var wg sync.WaitGroup
+	for i := range connections {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, err := http.NewRequestWithContext(ctx, http.MethodHead, target.url, nil)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			resp, err := target.client.Do(req)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// trackPools assigns a new client's transports to its provider's pool stats
+func (m *Manager) trackPools(provider domain.Provider, client domain.LLMClient) {
+	pool, ok := client.(connectionPool)
+	if !ok {
+		return
+	}
+	stats := m.poolStatsFor(provider)
+	for _, target := range pool.poolTargets() {
+		if t, ok := target.client.Transport.(*poolTransport); ok {
+			t.stats.CompareAndSwap(nil, stats)
+		}
+	}
+}
+
+// poolStatsFor returns the shared pool stats of a provider
+func (m *Manager) poolStatsFor(provider domain.Provider) *poolStats {
+	m.poolMu.Lock()
+	defer m.poolMu.Unlock()
+	if m.pools == nil {
+		m.pools = make(map[domain.Provider]*poolStats)
+	}
+	stats, ok := m.pools[provider]
+	if !ok {
+		stats = &poolStats{provider: string(provider), observer: m.poolObserver}
+		m.pools[provider] = stats
+	}
+	return stats
+}
+
+// SetPoolObserver reports the connection pools of clients created from now on
+// to o. Call before the first client is created.
+func (m *Manager) SetPoolObserver(o PoolObserver) {
+	m.poolMu.Lock()
+	defer m.poolMu.Unlock()
+	m.poolObserver = o
+}
+
+// warmClient opens connections for every target of a client
+func warmClient(ctx context.Context, client domain.LLMClient, connections int) error {
+	pool, ok := client.(connectionPool)
+	if !ok {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, warmTimeout)
+	defer cancel()
+
+	var errs []error
+	for _, target := range pool.poolTargets() {
+		if target.client == nil || target.url == "" {
+			continue
+		}
+		if err := warmTarget(ctx, target, connections); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", target.url, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// WarmTenantClients opens connections for every cached client of a tenant,
+// as configured by [connection_warmup]. It does nothing when warm-up is off.
+func (m *Manager) WarmTenantClients(ctx context.Context, tenantID string) {
+	current := m.config.Load()
+	if current == nil || !current.Warmup.Enabled {
+		return
+	}
+	cfg := current.Warmup
+
+	type cached struct {
+		provider domain.Provider
+		client   domain.LLMClient
+	}
+	m.mu.RLock()
+	var clients []cached
+	for key, client := range m.tenantClients[tenantID] {
+		clients = append(clients, cached{key.provider, client})
+	}
+	m.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, c := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			if err := warmClient(ctx, c.client, cfg.Connections); err != nil {
+				slog.Warn("Failed to warm provider connections", "tenant_id", tenantID, "provider", c.provider, "error", err)
+				return
+			}
+			slog.Debug("Warmed provider connections", "tenant_id", tenantID, "provider", c.provider, "duration", time.Since(start))
+		}()
+	}
+	wg.Wait()
+}
+
+// StartKeepAlive pings the connections of every cached client every
+// [connection_warmup] ping_interval, so a quiet provider's connections aren't
+// closed as idle before the next request. The interval is re-read after each
+// round, so reloads take effect.
+func (m *Manager) StartKeepAlive(ctx context.Context) {
+	go func() {
+		for {
+			var cfg config.ConnectionWarmupConfig
+			if current := m.config.Load(); current != nil {
+				cfg = current.Warmup
+			}
+			interval := cfg.PingInterval
+			if interval <= 0 {
+				interval = time.Minute // Check again later in case a reload turns it on
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(interval):
+			}
+			if !cfg.Enabled || cfg.PingInterval <= 0 {
+				continue
+			}
+
+			m.mu.RLock()
+			tenants := make([]string, 0, len(m.tenantClients))
+			for tenantID := range m.tenantClients {
+				tenants = append(tenants, tenantID)
+			}
+			m.mu.RUnlock()
+			for _, tenantID := range tenants {
+				m.WarmTenantClients(ctx, tenantID)
+			}
+		}
+	}()
+}
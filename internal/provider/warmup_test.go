@@ -0,0 +1,161 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+)
+
+// fakePoolObserver keeps the last reported pool of each provider
+type fakePoolObserver struct {
+	mu     sync.Mutex
+	active map[string]int
+	idle   map[string]int
+	waits  map[string]int
+}
+
+func newFakePoolObserver() *fakePoolObserver {
+	return &fakePoolObserver{active: map[string]int{}, idle: map[string]int{}, waits: map[string]int{}}
+}
+
+func (o *fakePoolObserver) UpdateProviderPool(provider string, active, idle int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.active[provider], o.idle[provider] = active, idle
+}
+
+func (o *fakePoolObserver) RecordProviderPoolWait(provider string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.waits[provider]++
+}
+
+func (o *fakePoolObserver) get(provider string) (active, idle, waits int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.active[provider], o.idle[provider], o.waits[provider]
+}
+
+// withConnTrace reports whether the request's connection was reused
+func withConnTrace(reused chan<- bool) context.Context {
+	return httptrace.WithClientTrace(context.Background(), &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) { reused <- info.Reused },
+	})
+}
+
+func TestWarmTenantClients(t *testing.T) {
+	var heads atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			heads.Add(1)
+			time.Sleep(20 * time.Millisecond) // Overlap so each HEAD takes its own connection
+			return
+		}
+		w.Write([]byte(`{"models":[]}`))
+	}))
+	defer srv.Close()
+
+	cfg := config.Default()
+	cfg.Warmup.Connections = 3
+	m, _ := NewManager(cfg)
+	observer := newFakePoolObserver()
+	m.SetPoolObserver(observer)
+
+	settings := domain.DefaultConnectionSettings()
+	settings.MaxIdleConnections = 2 // Caps the warmed connections
+	client, err := m.GetOrCreateTenantClient("default", domain.ProviderOllama, &domain.ProviderConfig{
+		Provider:           domain.ProviderOllama,
+		Enabled:            true,
+		BaseURL:            srv.URL,
+		ConnectionSettings: settings,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m.WarmTenantClients(context.Background(), "default")
+	if got := heads.Load(); got != 2 {
+		t.Fatalf("expected 2 warm-up requests, got %d", got)
+	}
+	if active, idle, _ := observer.get("ollama"); active != 0 || idle != 2 {
+		t.Fatalf("expected 2 idle connections after warm-up, got active=%d idle=%d", active, idle)
+	}
+
+	// The next request reuses a warmed connection
+	reused := make(chan bool, 1)
+	if _, err := client.ListModels(withConnTrace(reused)); err != nil {
+		t.Fatal(err)
+	}
+	if !<-reused {
+		t.Fatal("expected the first request to reuse a warmed connection")
+	}
+
+	// Warm-up is off
+	off := config.Default()
+	off.Warmup.Enabled = false
+	m.SetConfig(off)
+	heads.Store(0)
+	m.WarmTenantClients(context.Background(), "default")
+	if got := heads.Load(); got != 0 {
+		t.Fatalf("expected no warm-up requests when disabled, got %d", got)
+	}
+}
+
+func TestPoolWaits(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+
+	m, _ := NewManager(config.Default())
+	observer := newFakePoolObserver()
+	m.SetPoolObserver(observer)
+
+	settings := domain.DefaultConnectionSettings()
+	settings.MaxConnections = 1
+	settings.EnableHTTP2 = false
+	httpClient := BuildHTTPClient(settings)
+	m.trackPools(domain.ProviderOllama, &OllamaClient{httpClient: httpClient, baseURL: srv.URL})
+
+	var wg sync.WaitGroup
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := httpClient.Get(srv.URL)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			resp.Body.Close()
+		}()
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, _, waits := observer.get("ollama"); waits == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected the second request to wait for the only connection")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if active, _, _ := observer.get("ollama"); active != 2 {
+		t.Fatalf("expected 2 requests in flight, got %d", active)
+	}
+	close(release)
+	wg.Wait()
+
+	if active, idle, _ := observer.get("ollama"); active != 0 || idle != 1 {
+		t.Fatalf("expected the connection to be idle, got active=%d idle=%d", active, idle)
+	}
+}
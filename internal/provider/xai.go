@@ -0,0 +1,409 @@
+// Package provider implements LLM provider clients.
+package provider
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"modelgate/internal/domain"
+)
+
+const xaiAPIURL = "https://api.x.ai/v1"
+
+// XAIConfig contains xAI-specific settings
+type XAIConfig struct {
+	APIKey             string
+	BaseURL            string // Optional override of the API URL
+	ConnectionSettings domain.ConnectionSettings
+}
+
+// XAIClient implements the LLMClient interface for xAI Grok models.
+// The xAI API is OpenAI-compatible; models are addressed as "xai/<model>".
+type XAIClient struct {
+	apiKey     string
+	baseURL    string
+	httpClient *http.Client
+	modelCache map[string]string // Cache of model aliases to native model IDs
+}
+
+// xaiModels is the Grok model list with xAI's published pricing (USD per 1M tokens)
+var xaiModels = []domain.ModelInfo{
+	{ID: "grok-4", Name: "Grok 4", SupportsTools: true, SupportsReasoning: true, ContextLimit: 256000, InputCostPer1M: 3.00, OutputCostPer1M: 15.00},
+	{ID: "grok-4-fast-reasoning", Name: "Grok 4 Fast (Reasoning)", SupportsTools: true, SupportsReasoning: true, ContextLimit: 2000000, InputCostPer1M: 0.20, OutputCostPer1M: 0.50},
+	{ID: "grok-4-fast-non-reasoning", Name: "Grok 4 Fast", SupportsTools: true, ContextLimit: 2000000, InputCostPer1M: 0.20, OutputCostPer1M: 0.50},
+	{ID: "grok-code-fast-1", Name: "Grok Code Fast", SupportsTools: true, SupportsReasoning: true, ContextLimit: 256000, InputCostPer1M: 0.20, OutputCostPer1M: 1.50},
+	{ID: "grok-3", Name: "Grok 3", SupportsTools: true, ContextLimit: 131072, InputCostPer1M: 3.00, OutputCostPer1M: 15.00},
+	{ID: "grok-3-mini", Name: "Grok 3 Mini", SupportsTools: true, SupportsReasoning: true, ContextLimit: 131072, InputCostPer1M: 0.30, OutputCostPer1M: 0.50},
+	{ID: "grok-2-vision-1212", Name: "Grok 2 Vision", SupportsVision: true, ContextLimit: 32768, InputCostPer1M: 2.00, OutputCostPer1M: 10.00},
+}
+
+// NewXAIClient creates a new xAI client
+func NewXAIClient(cfg XAIConfig) (*XAIClient, error) {
+	if cfg.APIKey == "" {
+		return nil, fmt.Errorf("xAI API key is required")
+	}
+
+	connSettings := cfg.ConnectionSettings
+	if connSettings.MaxConnections == 0 {
+		connSettings = domain.DefaultConnectionSettings()
+	}
+
+	baseURL := strings.TrimSuffix(cfg.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = xaiAPIURL
+	}
+
+	return &XAIClient{
+		apiKey:     cfg.APIKey,
+		baseURL:    baseURL,
+		httpClient: BuildHTTPClient(connSettings),
+		modelCache: make(map[string]string),
+	}, nil
+}
+
+// SetModelCache sets the model cache (implements ModelCacheable)
+func (c *XAIClient) SetModelCache(cache map[string]string) {
+	c.modelCache = cache
+}
+
+// GetModelCache returns the model cache (implements ModelCacheable)
+func (c *XAIClient) GetModelCache() map[string]string {
+	return c.modelCache
+}
+
+// resolveModelID resolves a model ID using the cache if available
+func (c *XAIClient) resolveModelID(model string) string {
+	if c.modelCache != nil {
+		if nativeID, ok := c.modelCache[model]; ok {
+			return ExtractModelID(nativeID)
+		}
+	}
+	return ExtractModelID(model)
+}
+
+// Provider returns the provider type
+func (c *XAIClient) Provider() domain.Provider {
+	return domain.ProviderXAI
+}
+
+// SupportsModel checks if a model is supported
+func (c *XAIClient) SupportsModel(model string) bool {
+	return strings.HasPrefix(strings.ToLower(c.resolveModelID(model)), "grok")
+}
+
+// ChatStream performs streaming chat completion
+func (c *XAIClient) ChatStream(ctx context.Context, req *domain.ChatRequest) (<-chan domain.StreamEvent, error) {
+	events := make(chan domain.StreamEvent, 100)
+
+	go func() {
+		defer close(events)
+
+		body := c.buildRequest(req)
+		body["stream"] = true
+		body["stream_options"] = map[string]any{"include_usage": true}
+
+		httpReq, err := c.newRequest(ctx, "POST", "/chat/completions", body)
+		if err != nil {
+			events <- domain.FinishEvent{Reason: domain.FinishReasonError}
+			return
+		}
+
+		resp, err := c.httpClient.Do(httpReq)
+		if err != nil {
+			events <- domain.FinishEvent{Reason: domain.FinishReasonError}
+			return
+		}
+		defer resp.Body.Close()
+		observeQuota(ctx, resp.Header)
+
+		if resp.StatusCode != http.StatusOK {
+			bodyBytes, _ := io.ReadAll(resp.Body)
+			events <- domain.ErrorEvent{Err: NewHTTPError(c.Provider(), resp.StatusCode, bodyBytes)}
+			events <- domain.FinishEvent{Reason: domain.FinishReasonError}
+			return
+		}
+
+		c.processSSEStream(resp.Body, events)
+	}()
+
+	return events, nil
+}
+
+// ChatComplete performs non-streaming chat completion
+func (c *XAIClient) ChatComplete(ctx context.Context, req *domain.ChatRequest) (*domain.ChatResponse, error) {
+	httpReq, err := c.newRequest(ctx, "POST", "/chat/completions", c.buildRequest(req))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	observeQuota(ctx, resp.Header)
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, NewHTTPError(c.Provider(), resp.StatusCode, bodyBytes)
+	}
+
+	var result struct {
+		Choices []struct {
+			Message struct {
+				Content          string `json:"content"`
+				ReasoningContent string `json:"reasoning_content"`
+				ToolCalls        []struct {
+					ID       string `json:"id"`
+					Type     string `json:"type"`
+					Function struct {
+						Name      string `json:"name"`
+						Arguments string `json:"arguments"`
+					} `json:"function"`
+				} `json:"tool_calls"`
+			} `json:"message"`
+			FinishReason string `json:"finish_reason"`
+		} `json:"choices"`
+		Usage struct {
+			PromptTokens     int32 `json:"prompt_tokens"`
+			CompletionTokens int32 `json:"completion_tokens"`
+			TotalTokens      int32 `json:"total_tokens"`
+		} `json:"usage"`
+		Model string `json:"model"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	response := &domain.ChatResponse{
+		Model: result.Model,
+		Usage: &domain.UsageEvent{
+			PromptTokens:     result.Usage.PromptTokens,
+			CompletionTokens: result.Usage.CompletionTokens,
+			TotalTokens:      result.Usage.TotalTokens,
+		},
+	}
+
+	if len(result.Choices) > 0 {
+		choice := result.Choices[0]
+		response.Content = choice.Message.Content
+		response.Thinking = choice.Message.ReasoningContent
+		response.FinishReason = domain.FinishReason(choice.FinishReason)
+
+		for _, tc := range choice.Message.ToolCalls {
+			var args map[string]any
+			json.Unmarshal([]byte(tc.Function.Arguments), &args)
+			response.ToolCalls = append(response.ToolCalls, domain.ToolCall{
+				ID:   tc.ID,
+				Type: tc.Type,
+				Function: domain.FunctionCall{
+					Name:      tc.Function.Name,
+					Arguments: args,
+				},
+			})
+		}
+	}
+
+	return response, nil
+}
+
+// Embed generates embeddings (xAI has no embeddings endpoint)
+func (c *XAIClient) Embed(ctx context.Context, model string, texts []string, dimensions *int32) ([][]float32, int64, error) {
+	return nil, 0, fmt.Errorf("xAI does not support embeddings")
+}
+
+// CountTokens counts tokens in a request
+func (c *XAIClient) CountTokens(ctx context.Context, req *domain.ChatRequest) (int32, error) {
+	var total int32
+	for _, msg := range req.Messages {
+		for _, block := range msg.Content {
+			if block.Type == "text" {
+				total += int32(len(block.Text) / 4)
+			}
+		}
+	}
+	return total, nil
+}
+
+// ListModels lists the Grok models with their pricing
+func (c *XAIClient) ListModels(ctx context.Context) ([]domain.ModelInfo, error) {
+	models := make([]domain.ModelInfo, len(xaiModels))
+	for i, m := range xaiModels {
+		m.Provider = domain.ProviderXAI
+		m.Enabled = true
+		models[i] = m
+	}
+	return models, nil
+}
+
+// Helper methods
+
+// newRequest builds an authenticated request against the xAI API
+func (c *XAIClient) newRequest(ctx context.Context, method, path string, body map[string]any) (*http.Request, error) {
+	var reader io.Reader
+	if body != nil {
+		jsonBody, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = strings.NewReader(string(jsonBody))
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	return httpReq, nil
+}
+
+func (c *XAIClient) buildRequest(req *domain.ChatRequest) map[string]any {
+	body := map[string]any{
+		"model":    c.resolveModelID(req.Model),
+		"messages": openAICompatibleMessages(req), // Images go as image_url parts, for the vision model
+	}
+
+	if req.Temperature != nil {
+		body["temperature"] = *req.Temperature
+	}
+	if req.TopP != nil {
+		body["top_p"] = *req.TopP
+	}
+	if req.MaxTokens != nil {
+		body["max_tokens"] = *req.MaxTokens
+	}
+	if len(req.Tools) > 0 {
+		body["tools"] = c.convertTools(req.Tools)
+		if tc := openAIToolChoice(req.ToolChoice); tc != nil {
+			body["tool_choice"] = tc
+		}
+	}
+	if rf := openAIResponseFormat(req.ResponseFormat); rf != nil {
+		body["response_format"] = rf
+	}
+
+	return body
+}
+
+func (c *XAIClient) convertTools(tools []domain.Tool) []map[string]any {
+	result := make([]map[string]any, len(tools))
+	for i, tool := range tools {
+		result[i] = map[string]any{
+			"type": "function",
+			"function": map[string]any{
+				"name":        tool.Function.Name,
+				"description": tool.Function.Description,
+				"parameters":  tool.Function.Parameters,
+			},
+		}
+	}
+	return result
+}
+
+// processSSEStream reads OpenAI-style SSE chunks. Reasoning models stream their
+// thinking as reasoning_content; tool call fragments are passed on as deltas
+// and accumulated by index.
+func (c *XAIClient) processSSEStream(body io.Reader, events chan<- domain.StreamEvent) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var inputTokens, outputTokens int32
+	var finishReason domain.FinishReason
+	var toolCalls toolCallStream
+
+	finish := func() {
+		toolCalls.flush(events)
+		events <- domain.UsageEvent{
+			PromptTokens:     inputTokens,
+			CompletionTokens: outputTokens,
+			TotalTokens:      inputTokens + outputTokens,
+		}
+		if finishReason == "" {
+			finishReason = domain.FinishReasonStop
+		}
+		events <- domain.FinishEvent{Reason: finishReason}
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+
+		data := strings.TrimPrefix(line, "data: ")
+		if data == "[DONE]" {
+			finish()
+			return
+		}
+
+		var chunk struct {
+			Choices []struct {
+				Delta struct {
+					Content          string `json:"content"`
+					ReasoningContent string `json:"reasoning_content"`
+					ToolCalls        []struct {
+						Index    int    `json:"index"`
+						ID       string `json:"id"`
+						Function struct {
+							Name      string `json:"name"`
+							Arguments string `json:"arguments"`
+						} `json:"function"`
+					} `json:"tool_calls"`
+				} `json:"delta"`
+				FinishReason string `json:"finish_reason"`
+			} `json:"choices"`
+			Usage *struct {
+				PromptTokens     int32 `json:"prompt_tokens"`
+				CompletionTokens int32 `json:"completion_tokens"`
+			} `json:"usage"`
+		}
+
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			continue
+		}
+
+		if chunk.Usage != nil {
+			inputTokens = chunk.Usage.PromptTokens
+			outputTokens = chunk.Usage.CompletionTokens
+		}
+
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+
+		delta := chunk.Choices[0].Delta
+		if delta.ReasoningContent != "" {
+			events <- domain.ThinkingChunk{Content: delta.ReasoningContent}
+		}
+		if delta.Content != "" {
+			events <- domain.TextChunk{Content: delta.Content}
+		}
+
+		for _, tc := range delta.ToolCalls {
+			toolCalls.add(events, tc.Index, tc.ID, tc.Function.Name, tc.Function.Arguments)
+		}
+
+		// With include_usage, usage arrives in a final chunk after the finish reason
+		switch chunk.Choices[0].FinishReason {
+		case "":
+		case "tool_calls":
+			finishReason = domain.FinishReasonToolCalls
+		case "length":
+			finishReason = domain.FinishReasonLength
+		default:
+			finishReason = domain.FinishReasonStop
+		}
+	}
+
+	finish()
+}
@@ -0,0 +1,126 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"modelgate/internal/domain"
+)
+
+func TestXAIRequest(t *testing.T) {
+	c := &XAIClient{}
+	body := c.buildRequest(&domain.ChatRequest{
+		Model:        "xai/grok-2-vision-1212",
+		SystemPrompt: "Be brief.",
+		Messages: []domain.Message{
+			{Role: "user", Content: []domain.ContentBlock{
+				{Type: "text", Text: "What is in this picture?"},
+				{Type: "image", ImageURL: "data:image/png;base64,iVBORw0KGgo="},
+			}},
+			{Role: "assistant", ToolCalls: []domain.ToolCall{{ID: "call_1", Function: domain.FunctionCall{Name: "lookup", Arguments: map[string]any{"q": "cat"}}}}},
+			{Role: "tool", ToolCallID: "call_1", Content: []domain.ContentBlock{{Type: "text", Text: "a cat"}}},
+		},
+		Tools:      []domain.Tool{{Type: "function", Function: domain.FunctionDefinition{Name: "lookup"}}},
+		ToolChoice: &domain.ToolChoice{Mode: "required", Function: "lookup"},
+	})
+	if body["model"] != "grok-2-vision-1212" {
+		t.Errorf("model = %v", body["model"])
+	}
+	if want := map[string]any{"type": "function", "function": map[string]any{"name": "lookup"}}; !reflect.DeepEqual(body["tool_choice"], want) {
+		t.Errorf("tool_choice = %v", body["tool_choice"])
+	}
+
+	messages := body["messages"].([]map[string]any)
+	if len(messages) != 4 || messages[0]["content"] != "Be brief." || messages[3]["tool_call_id"] != "call_1" {
+		t.Fatalf("unexpected messages %v", messages)
+	}
+	parts, ok := messages[1]["content"].([]map[string]any)
+	if !ok || len(parts) != 2 || parts[1]["type"] != "image_url" {
+		t.Fatalf("expected a text and an image_url part, got %v", messages[1]["content"])
+	}
+	if url := parts[1]["image_url"].(map[string]string)["url"]; url != "data:image/png;base64,iVBORw0KGgo=" {
+		t.Errorf("image url = %q", url)
+	}
+	if messages[3]["content"] != "a cat" {
+		t.Errorf("expected text-only messages to keep string content, got %v", messages[3]["content"])
+	}
+}
+
+func TestXAIStream(t *testing.T) {
+	stream := strings.Join([]string{
+		`data: {"choices":[{"delta":{"reasoning_content":"Look it up."}}]}`,
+		`data: {"choices":[{"delta":{"content":"Checking."}}]}`,
+		`data: {"choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_1","function":{"name":"lookup","arguments":"{\"q\":"}}]}}]}`,
+		`data: {"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"cat\"}"}}]},"finish_reason":"tool_calls"}]}`,
+		`data: {"choices":[],"usage":{"prompt_tokens":40,"completion_tokens":10}}`,
+		`data: [DONE]`,
+	}, "\n\n") + "\n\n"
+	events := collect(func(events chan<- domain.StreamEvent) {
+		(&XAIClient{}).processSSEStream(strings.NewReader(stream), events)
+	})
+
+	var thinking, text string
+	var call *domain.ToolCall
+	var usage *domain.UsageEvent
+	var finish domain.FinishReason
+	for _, event := range events {
+		switch e := event.(type) {
+		case domain.ThinkingChunk:
+			thinking += e.Content
+		case domain.TextChunk:
+			text += e.Content
+		case domain.ToolCallEvent:
+			call = &e.ToolCall
+		case domain.UsageEvent:
+			usage = &e
+		case domain.FinishEvent:
+			finish = e.Reason
+		}
+	}
+	if thinking != "Look it up." || text != "Checking." {
+		t.Errorf("thinking = %q, text = %q", thinking, text)
+	}
+	if call == nil || call.ID != "call_1" || call.Function.Arguments["q"] != "cat" {
+		t.Errorf("unexpected tool call %+v", call)
+	}
+	if usage == nil || usage.TotalTokens != 50 {
+		t.Errorf("unexpected usage %+v", usage)
+	}
+	if finish != domain.FinishReasonToolCalls {
+		t.Errorf("finish reason = %s", finish)
+	}
+}
+
+func TestXAIComplete(t *testing.T) {
+	var auth string
+	var sent map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&sent)
+		w.Write([]byte(`{"model":"grok-3-mini","choices":[{"message":{"content":"Hi.","reasoning_content":"Greet back."},"finish_reason":"stop"}],"usage":{"prompt_tokens":12,"completion_tokens":3,"total_tokens":15}}`))
+	}))
+	defer server.Close()
+
+	c, err := NewXAIClient(XAIConfig{APIKey: "xai-key", BaseURL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.ChatComplete(context.Background(), &domain.ChatRequest{
+		Model:    "xai/grok-3-mini",
+		Messages: []domain.Message{{Role: "user", Content: []domain.ContentBlock{{Type: "text", Text: "Hello"}}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if auth != "Bearer xai-key" || sent["model"] != "grok-3-mini" {
+		t.Errorf("unexpected request: auth %q, body %v", auth, sent)
+	}
+	if resp.Content != "Hi." || resp.Thinking != "Greet back." || resp.FinishReason != domain.FinishReasonStop || resp.Usage.TotalTokens != 15 {
+		t.Errorf("unexpected response %+v", resp)
+	}
+}
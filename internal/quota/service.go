@@ -0,0 +1,242 @@
+// Package quota manages monthly billing periods: it rolls periods over,
+// snapshots each period's usage and prorates limit changes made mid-period.
+package quota
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+
+	"modelgate/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+const (
+	rolloverInterval = 10 * time.Minute // How often the open period is checked for expiry
+	maxHistory       = 120              // Largest number of periods a client may list
+)
+
+// Store is the persistence used by the service (implemented by postgres.TenantStore)
+type Store interface {
+	GetQuotaLimits(ctx context.Context) (*domain.QuotaLimits, error)
+	SaveQuotaLimits(ctx context.Context, limits *domain.QuotaLimits) error
+	GetOpenQuotaPeriod(ctx context.Context) (*domain.QuotaPeriod, error)
+	CreateQuotaPeriod(ctx context.Context, p *domain.QuotaPeriod) error
+	UpdateQuotaPeriodLimits(ctx context.Context, p *domain.QuotaPeriod) error
+	CloseQuotaPeriod(ctx context.Context, p *domain.QuotaPeriod) error
+	ListQuotaPeriods(ctx context.Context, limit int) ([]*domain.QuotaPeriod, error)
+	GetUsageTotals(ctx context.Context, start, end time.Time) (*domain.UsageStats, error)
+}
+
+// Service manages billing periods
+type Service struct {
+	store Store
+	now   func() time.Time
+
+	mu sync.Mutex // Serializes rollovers and limit changes within this instance
+}
+
+// NewService creates a new quota service
+func NewService(store Store) *Service {
+	return &Service{store: store, now: time.Now}
+}
+
+// periodBounds returns the calendar month (UTC) containing t
+func periodBounds(t time.Time) (time.Time, time.Time) {
+	t = t.UTC()
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, 0)
+}
+
+// prorate returns a period's effective limit after the configured limit
+// changes from old to next with fraction remaining of the period left
+func prorate(effective, old, next int64, remaining float64) int64 {
+	switch {
+	case next == 0:
+		return 0 // Unlimited from now on
+	case old == 0:
+		return int64(math.Round(float64(next) * remaining))
+	default:
+		return max(0, effective+int64(math.Round(float64(next-old)*remaining)))
+	}
+}
+
+func prorateCost(effective, old, next, remaining float64) float64 {
+	switch {
+	case next == 0:
+		return 0
+	case old == 0:
+		return next * remaining
+	default:
+		return max(0, effective+(next-old)*remaining)
+	}
+}
+
+// Limits returns the configured limits (zero values if never set)
+func (s *Service) Limits(ctx context.Context) (*domain.QuotaLimits, error) {
+	limits, err := s.store.GetQuotaLimits(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get quota limits: %w", err)
+	}
+	if limits == nil {
+		limits = &domain.QuotaLimits{}
+	}
+	return limits, nil
+}
+
+// Rollover closes every period that has ended, snapshotting its usage, and
+// opens the period containing the current time. It returns the open period.
+func (s *Service) Rollover(ctx context.Context) (*domain.QuotaPeriod, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rollover(ctx)
+}
+
+func (s *Service) rollover(ctx context.Context) (*domain.QuotaPeriod, error) {
+	now := s.now()
+	for {
+		open, err := s.store.GetOpenQuotaPeriod(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("get open quota period: %w", err)
+		}
+		if open != nil && now.Before(open.PeriodEnd) {
+			return open, nil
+		}
+
+		start, _ := periodBounds(now)
+		if open != nil {
+			usage, err := s.store.GetUsageTotals(ctx, open.PeriodStart, open.PeriodEnd)
+			if err != nil {
+				return nil, fmt.Errorf("get period usage: %w", err)
+			}
+			open.RequestsUsed = usage.TotalRequests
+			open.TokensUsed = usage.TotalTokens
+			open.CostUsedUSD = usage.TotalCostUSD
+			if err := s.store.CloseQuotaPeriod(ctx, open); err != nil {
+				return nil, fmt.Errorf("close quota period: %w", err)
+			}
+			slog.Info("Closed billing period",
+				"period_start", open.PeriodStart, "requests", open.RequestsUsed,
+				"tokens", open.TokensUsed, "cost_usd", open.CostUsedUSD)
+			// Periods follow each other without gaps, even across downtime
+			start = open.PeriodEnd
+		}
+
+		limits, err := s.Limits(ctx)
+		if err != nil {
+			return nil, err
+		}
+		_, end := periodBounds(start)
+		next := &domain.QuotaPeriod{
+			ID: uuid.New().String(),
+			TenantQuotas: domain.TenantQuotas{
+				RequestsLimit: limits.RequestsLimit,
+				TokensLimit:   limits.TokensLimit,
+				CostLimitUSD:  limits.CostLimitUSD,
+				PeriodStart:   start,
+				PeriodEnd:     end,
+			},
+		}
+		if err := s.store.CreateQuotaPeriod(ctx, next); err != nil {
+			return nil, fmt.Errorf("create quota period: %w", err)
+		}
+	}
+}
+
+// Current returns the open period with its usage so far
+func (s *Service) Current(ctx context.Context) (*domain.QuotaPeriod, error) {
+	period, err := s.Rollover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	usage, err := s.store.GetUsageTotals(ctx, period.PeriodStart, period.PeriodEnd)
+	if err != nil {
+		return nil, fmt.Errorf("get period usage: %w", err)
+	}
+	period.RequestsUsed = usage.TotalRequests
+	period.TokensUsed = usage.TotalTokens
+	period.CostUsedUSD = usage.TotalCostUSD
+	return period, nil
+}
+
+// History returns the most recent periods, newest first. The open period's
+// usage is filled in up to now.
+func (s *Service) History(ctx context.Context, limit int) ([]*domain.QuotaPeriod, error) {
+	if limit <= 0 || limit > maxHistory {
+		limit = maxHistory
+	}
+	current, err := s.Current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	periods, err := s.store.ListQuotaPeriods(ctx, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list quota periods: %w", err)
+	}
+	for i, p := range periods {
+		if p.ID == current.ID {
+			periods[i] = current
+		}
+	}
+	return periods, nil
+}
+
+// SetLimits changes the configured limits. The open period's limits are
+// prorated: the old limits apply to the elapsed part of the period and the
+// new ones to the remainder. It returns the open period.
+func (s *Service) SetLimits(ctx context.Context, limits domain.QuotaLimits) (*domain.QuotaPeriod, error) {
+	if limits.RequestsLimit < 0 || limits.TokensLimit < 0 || limits.CostLimitUSD < 0 {
+		return nil, fmt.Errorf("quota limits cannot be negative")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	period, err := s.rollover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	old, err := s.Limits(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	total := period.PeriodEnd.Sub(period.PeriodStart)
+	remaining := float64(period.PeriodEnd.Sub(s.now())) / float64(total)
+	remaining = min(1, max(0, remaining))
+	period.RequestsLimit = prorate(period.RequestsLimit, old.RequestsLimit, limits.RequestsLimit, remaining)
+	period.TokensLimit = prorate(period.TokensLimit, old.TokensLimit, limits.TokensLimit, remaining)
+	period.CostLimitUSD = prorateCost(period.CostLimitUSD, old.CostLimitUSD, limits.CostLimitUSD, remaining)
+
+	if err := s.store.SaveQuotaLimits(ctx, &limits); err != nil {
+		return nil, fmt.Errorf("save quota limits: %w", err)
+	}
+	if err := s.store.UpdateQuotaPeriodLimits(ctx, period); err != nil {
+		return nil, fmt.Errorf("update quota period: %w", err)
+	}
+	return period, nil
+}
+
+// Start rolls periods over in the background until ctx is cancelled
+func (s *Service) Start(ctx context.Context) {
+	go func() {
+		if _, err := s.Rollover(ctx); err != nil {
+			slog.Error("Failed to roll over billing period", "error", err)
+		}
+		ticker := time.NewTicker(rolloverInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := s.Rollover(ctx); err != nil {
+					slog.Error("Failed to roll over billing period", "error", err)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,146 @@
+package quota
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+type usage struct {
+	at     time.Time
+	tokens int64
+	cost   float64
+}
+
+type memStore struct {
+	limits  *domain.QuotaLimits
+	periods []*domain.QuotaPeriod
+	usage   []usage
+}
+
+func (m *memStore) GetQuotaLimits(ctx context.Context) (*domain.QuotaLimits, error) {
+	return m.limits, nil
+}
+
+func (m *memStore) SaveQuotaLimits(ctx context.Context, limits *domain.QuotaLimits) error {
+	l := *limits
+	m.limits = &l
+	return nil
+}
+
+func (m *memStore) GetOpenQuotaPeriod(ctx context.Context) (*domain.QuotaPeriod, error) {
+	for _, p := range m.periods {
+		if p.ClosedAt == nil {
+			c := *p
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memStore) CreateQuotaPeriod(ctx context.Context, p *domain.QuotaPeriod) error {
+	c := *p
+	m.periods = append(m.periods, &c)
+	return nil
+}
+
+func (m *memStore) find(id string) *domain.QuotaPeriod {
+	for _, p := range m.periods {
+		if p.ID == id {
+			return p
+		}
+	}
+	return nil
+}
+
+func (m *memStore) UpdateQuotaPeriodLimits(ctx context.Context, p *domain.QuotaPeriod) error {
+	stored := m.find(p.ID)
+	stored.RequestsLimit, stored.TokensLimit, stored.CostLimitUSD = p.RequestsLimit, p.TokensLimit, p.CostLimitUSD
+	return nil
+}
+
+func (m *memStore) CloseQuotaPeriod(ctx context.Context, p *domain.QuotaPeriod) error {
+	stored := m.find(p.ID)
+	now := time.Now()
+	stored.RequestsUsed, stored.TokensUsed, stored.CostUsedUSD = p.RequestsUsed, p.TokensUsed, p.CostUsedUSD
+	stored.ClosedAt = &now
+	return nil
+}
+
+func (m *memStore) ListQuotaPeriods(ctx context.Context, limit int) ([]*domain.QuotaPeriod, error) {
+	out := make([]*domain.QuotaPeriod, 0, len(m.periods))
+	for _, p := range m.periods {
+		c := *p
+		out = append(out, &c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].PeriodStart.After(out[j].PeriodStart) })
+	return out[:min(limit, len(out))], nil
+}
+
+func (m *memStore) GetUsageTotals(ctx context.Context, start, end time.Time) (*domain.UsageStats, error) {
+	var stats domain.UsageStats
+	for _, u := range m.usage {
+		if !u.at.Before(start) && u.at.Before(end) {
+			stats.TotalRequests++
+			stats.TotalTokens += u.tokens
+			stats.TotalCostUSD += u.cost
+		}
+	}
+	return &stats, nil
+}
+
+func TestPeriodRolloverAndProration(t *testing.T) {
+	ctx := context.Background()
+	store := &memStore{}
+	svc := NewService(store)
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+
+	if _, err := svc.SetLimits(ctx, domain.QuotaLimits{RequestsLimit: 1000, CostLimitUSD: 100}); err != nil {
+		t.Fatal(err)
+	}
+	store.usage = append(store.usage,
+		usage{at: now.Add(time.Hour), tokens: 500, cost: 1.5},
+		usage{at: now.Add(2 * time.Hour), tokens: 300, cost: 0.5},
+	)
+
+	// Halfway through a 30-day month the limits double: the period gets 1.5x
+	now = time.Date(2026, 4, 16, 0, 0, 0, 0, time.UTC)
+	period, err := svc.SetLimits(ctx, domain.QuotaLimits{RequestsLimit: 2000, CostLimitUSD: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !period.PeriodStart.Equal(time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected the April period to be open, got %v", period.PeriodStart)
+	}
+	if period.RequestsLimit != 1500 || period.CostLimitUSD != 150 {
+		t.Fatalf("expected prorated limits 1500/150, got %d/%v", period.RequestsLimit, period.CostLimitUSD)
+	}
+
+	// March was closed with its usage snapshot; the next period starts with the new limits
+	now = time.Date(2026, 5, 2, 0, 0, 0, 0, time.UTC)
+	history, err := svc.History(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 3 {
+		t.Fatalf("expected 3 periods, got %d", len(history))
+	}
+	may, april, march := history[0], history[1], history[2]
+	if may.ClosedAt != nil || may.RequestsLimit != 2000 || may.RequestsUsed != 0 {
+		t.Fatalf("unexpected current period: %+v", may)
+	}
+	if april.ClosedAt == nil || april.RequestsLimit != 1500 {
+		t.Fatalf("unexpected April period: %+v", april)
+	}
+	if march.ClosedAt == nil || march.RequestsUsed != 2 || march.TokensUsed != 800 || march.CostUsedUSD != 2 {
+		t.Fatalf("unexpected March snapshot: %+v", march)
+	}
+
+	if got := prorate(0, 1000, 0, 0.5); got != 0 {
+		t.Fatalf("removing a limit should make the period unlimited, got %d", got)
+	}
+}
@@ -0,0 +1,342 @@
+// Package readiness checks the dependencies the gateway needs to serve
+// traffic, for the /ready probe and the router's degraded mode.
+package readiness
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"modelgate/internal/domain"
+	"modelgate/internal/provider"
+)
+
+// Dependency statuses
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded" // Working, with reduced capacity
+	StatusDown     = "down"
+	StatusDisabled = "disabled" // Not in use, so not checked
+)
+
+// Overall statuses
+const (
+	Ready    = "ready"
+	Degraded = "degraded"  // Serving traffic with a non-critical dependency impaired
+	NotReady = "not_ready" // A critical dependency is down
+)
+
+// Names of the standard dependencies
+const (
+	DependencyPostgres   = "postgres"
+	DependencyProviders  = "providers"
+	DependencyDispatcher = "dispatcher"
+	DependencyEmbedder   = "embedder"
+	DependencyReplicas   = "replicas"
+)
+
+// checkTimeout bounds a single dependency check
+const checkTimeout = 3 * time.Second
+
+// Result is the outcome of checking one dependency
+type Result struct {
+	Status    string            `json:"status"`
+	Critical  bool              `json:"critical"` // Down means the instance isn't ready
+	Message   string            `json:"message,omitempty"`
+	LatencyMs int64             `json:"latency_ms"`
+	Details   map[string]string `json:"details,omitempty"` // e.g. the status of each provider
+}
+
+// Report is the readiness of the instance and each of its dependencies
+type Report struct {
+	Status       string            `json:"status"`
+	Degraded     bool              `json:"degraded"`
+	CheckedAt    time.Time         `json:"checked_at"`
+	Dependencies map[string]Result `json:"dependencies"`
+}
+
+// CheckFunc checks one dependency. Status, Message and Details are used
+// from the result it returns.
+type CheckFunc func(ctx context.Context) Result
+
+type check struct {
+	name     string
+	critical bool
+	fn       CheckFunc
+}
+
+// Checker runs dependency checks and keeps the latest report. Reports are
+// reused for maxAge, so frequent probes don't each hit every dependency.
+type Checker struct {
+	maxAge time.Duration
+	checks []check
+
+	runMu sync.Mutex // Held while checks run, so concurrent probes share one run
+	mu    sync.RWMutex
+	last  *Report
+}
+
+// NewChecker creates a checker that reuses reports for maxAge
+func NewChecker(maxAge time.Duration) *Checker {
+	return &Checker{maxAge: maxAge}
+}
+
+// Add registers a dependency check. A critical dependency being down makes
+// the instance not ready; any other problem only degrades it.
+func (c *Checker) Add(name string, critical bool, fn CheckFunc) {
+	c.checks = append(c.checks, check{name: name, critical: critical, fn: fn})
+}
+
+// Check returns the current report, checking every dependency concurrently
+// when the last report is older than maxAge
+func (c *Checker) Check(ctx context.Context) *Report {
+	c.runMu.Lock()
+	defer c.runMu.Unlock()
+	if last := c.latest(); last != nil && time.Since(last.CheckedAt) < c.maxAge {
+		return last
+	}
+
+	report := &Report{Status: Ready, CheckedAt: time.Now(), Dependencies: make(map[string]Result, len(c.checks))}
+	results := make([]Result, len(c.checks))
+	var wg sync.WaitGroup
+	for i, chk := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = run(ctx, chk)
+		}()
+	}
+	wg.Wait()
+
+	for i, chk := range c.checks {
+		result := results[i]
+		report.Dependencies[chk.name] = result
+		switch {
+		case result.Status == StatusDown && chk.critical:
+			report.Status = NotReady
+		case result.Status == StatusDown || result.Status == StatusDegraded:
+			if report.Status == Ready {
+				report.Status = Degraded
+			}
+		}
+	}
+	report.Degraded = report.Status != Ready
+
+	if last := c.latest(); last == nil || last.Status != report.Status {
+		slog.Info("Readiness changed", "status", report.Status)
+	}
+	c.mu.Lock()
+	c.last = report
+	c.mu.Unlock()
+	return report
+}
+
+func (c *Checker) latest() *Report {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.last
+}
+
+// run runs a check with a timeout, recovering from a check that doesn't return in time
+func run(ctx context.Context, chk check) Result {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan Result, 1)
+	go func() { done <- chk.fn(ctx) }()
+
+	var result Result
+	select {
+	case result = <-done:
+	case <-ctx.Done():
+		result = Result{Status: StatusDown, Message: "check timed out"}
+	}
+	result.Critical = chk.critical
+	result.LatencyMs = time.Since(start).Milliseconds()
+	return result
+}
+
+// Run refreshes the report every interval until ctx is done, so the
+// degraded flag stays current between probes
+func (c *Checker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	c.Check(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.Check(ctx)
+		}
+	}
+}
+
+// Degraded reports whether the latest report found any dependency impaired.
+// It is false until the first check.
+func (c *Checker) Degraded() bool {
+	last := c.latest()
+	return last != nil && last.Degraded
+}
+
+// ProviderReady reports whether the latest report found a usable key for
+// provider. Providers are assumed ready until the first check.
+func (c *Checker) ProviderReady(p string) bool {
+	last := c.latest()
+	if last == nil {
+		return true
+	}
+	providers, ok := last.Dependencies[DependencyProviders]
+	if !ok || providers.Details == nil {
+		return true
+	}
+	return providers.Details[p] == StatusOK
+}
+
+// Postgres checks that the database answers
+func Postgres(db *sql.DB) CheckFunc {
+	return func(ctx context.Context) Result {
+		if err := db.PingContext(ctx); err != nil {
+			return Result{Status: StatusDown, Message: err.Error()}
+		}
+		return Result{Status: StatusOK}
+	}
+}
+
+// Replicas reports the read replicas from their last health check. Reads
+// fall back to the primary, so a replica that isn't usable only degrades
+// the instance.
+func Replicas(states func() []domain.ReplicaState) CheckFunc {
+	return func(ctx context.Context) Result {
+		details := make(map[string]string)
+		var unusable []string
+		for _, r := range states() {
+			if r.Usable {
+				details[r.Name] = StatusOK
+				continue
+			}
+			details[r.Name] = r.Error
+			unusable = append(unusable, r.Name)
+		}
+		if len(unusable) == 0 {
+			return Result{Status: StatusOK, Details: details}
+		}
+		if len(unusable) == len(details) {
+			return Result{Status: StatusDegraded, Message: "no read replica is usable, analytics queries run on the primary", Details: details}
+		}
+		return Result{Status: StatusDegraded, Message: "not usable: " + strings.Join(unusable, ", "), Details: details}
+	}
+}
+
+// ProviderSource lists the configured providers (implemented by postgres.TenantStore)
+type ProviderSource interface {
+	ListProviderConfigs(ctx context.Context) ([]*domain.ProviderConfig, error)
+}
+
+// KeySource lists a provider's API keys (implemented by provider.KeySelector)
+type KeySource interface {
+	ListKeys(ctx context.Context, tenantSlug string, p domain.Provider) ([]*provider.ProviderAPIKey, error)
+}
+
+// Providers checks that enabled providers have a usable API key. It is down
+// when no provider does, and degraded when only some do. Providers that run
+// without a key, such as Ollama, only need to be enabled.
+func Providers(configs ProviderSource, keys KeySource) CheckFunc {
+	return func(ctx context.Context) Result {
+		cfgs, err := configs.ListProviderConfigs(ctx)
+		if err != nil {
+			return Result{Status: StatusDown, Message: fmt.Sprintf("list providers: %v", err)}
+		}
+
+		details := make(map[string]string)
+		var ready, notReady []string
+		now := time.Now()
+		for _, cfg := range cfgs {
+			if !cfg.Enabled {
+				continue
+			}
+			status := StatusOK
+			if cfg.Provider.RequiresAPIKey() {
+				providerKeys, err := keys.ListKeys(ctx, "default", cfg.Provider)
+				if err != nil {
+					return Result{Status: StatusDown, Message: fmt.Sprintf("list %s keys: %v", cfg.Provider, err)}
+				}
+				if !hasUsableKey(providerKeys, now) {
+					status = "no_valid_key"
+				}
+			}
+			details[string(cfg.Provider)] = status
+			if status == StatusOK {
+				ready = append(ready, string(cfg.Provider))
+			} else {
+				notReady = append(notReady, string(cfg.Provider))
+			}
+		}
+
+		switch {
+		case len(ready) == 0 && len(notReady) == 0:
+			return Result{Status: StatusDown, Message: "no provider is enabled", Details: details}
+		case len(ready) == 0:
+			return Result{Status: StatusDown, Message: "no enabled provider has a valid API key", Details: details}
+		case len(notReady) > 0:
+			sort.Strings(notReady)
+			return Result{Status: StatusDegraded, Message: "without a valid API key: " + strings.Join(notReady, ", "), Details: details}
+		}
+		return Result{Status: StatusOK, Details: details}
+	}
+}
+
+// hasUsableKey reports whether any key is enabled, didn't fail its last
+// validation and isn't cooling down after an authentication error
+func hasUsableKey(keys []*provider.ProviderAPIKey, now time.Time) bool {
+	for _, k := range keys {
+		if !k.Enabled || (k.LastValidationOK != nil && !*k.LastValidationOK) {
+			continue
+		}
+		if k.CooldownReason == provider.ErrorTypeAuthError && k.CooldownUntil != nil && k.CooldownUntil.After(now) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// DispatcherState reports the state of the request dispatcher (implemented by gateway.Dispatcher)
+type DispatcherState interface {
+	Running() bool
+	IsHealthy() bool
+}
+
+// Dispatcher checks that the dispatcher is running. It is degraded while
+// the queue is full.
+func Dispatcher(d DispatcherState) CheckFunc {
+	return func(ctx context.Context) Result {
+		switch {
+		case !d.Running():
+			return Result{Status: StatusDown, Message: "dispatcher is not running"}
+		case !d.IsHealthy():
+			return Result{Status: StatusDegraded, Message: "request queue is full"}
+		}
+		return Result{Status: StatusOK}
+	}
+}
+
+// Embedder checks that the semantic cache's embedder answers. It is skipped
+// while enabled reports false, i.e. no role uses the cache.
+func Embedder(embed func(ctx context.Context, texts []string) ([][]float32, error), enabled func(ctx context.Context) bool) CheckFunc {
+	return func(ctx context.Context) Result {
+		if !enabled(ctx) {
+			return Result{Status: StatusDisabled}
+		}
+		if _, err := embed(ctx, []string{"readiness check"}); err != nil {
+			return Result{Status: StatusDown, Message: err.Error()}
+		}
+		return Result{Status: StatusOK}
+	}
+}
@@ -0,0 +1,93 @@
+package readiness
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"modelgate/internal/domain"
+	"modelgate/internal/provider"
+)
+
+type fakeProviders []*domain.ProviderConfig
+
+func (f fakeProviders) ListProviderConfigs(ctx context.Context) ([]*domain.ProviderConfig, error) {
+	return f, nil
+}
+
+type fakeKeys map[domain.Provider][]*provider.ProviderAPIKey
+
+func (f fakeKeys) ListKeys(ctx context.Context, tenantSlug string, p domain.Provider) ([]*provider.ProviderAPIKey, error) {
+	return f[p], nil
+}
+
+func TestChecker(t *testing.T) {
+	failed := false
+	cooldown := time.Now().Add(time.Minute)
+	providers := fakeProviders{
+		{Provider: domain.ProviderOpenAI, Enabled: true},
+		{Provider: domain.ProviderAnthropic, Enabled: true},
+		{Provider: domain.ProviderGroq, Enabled: true},
+		{Provider: domain.ProviderOllama, Enabled: true},
+		{Provider: domain.ProviderGemini},
+	}
+	keys := fakeKeys{
+		domain.ProviderOpenAI:    {{Enabled: false}, {Enabled: true}},
+		domain.ProviderAnthropic: {{Enabled: true, LastValidationOK: &failed}},
+		domain.ProviderGroq:      {{Enabled: true, CooldownReason: provider.ErrorTypeAuthError, CooldownUntil: &cooldown}},
+	}
+
+	dispatcherUp := true
+	c := NewChecker(0)
+	c.Add(DependencyProviders, false, Providers(providers, keys))
+	c.Add(DependencyDispatcher, true, func(ctx context.Context) Result {
+		if dispatcherUp {
+			return Result{Status: StatusOK}
+		}
+		return Result{Status: StatusDown}
+	})
+
+	if !c.ProviderReady("anthropic") || c.Degraded() {
+		t.Fatal("expected everything to be assumed ready before the first check")
+	}
+	report := c.Check(context.Background())
+	if report.Status != Degraded || !c.Degraded() {
+		t.Fatalf("expected degraded with some providers unusable, got %s", report.Status)
+	}
+	for p, ready := range map[string]bool{"openai": true, "ollama": true, "anthropic": false, "groq": false, "gemini": false} {
+		if c.ProviderReady(p) != ready {
+			t.Errorf("expected %s ready=%t, got %+v", p, ready, report.Dependencies[DependencyProviders].Details)
+		}
+	}
+
+	// A non-critical dependency being down only degrades the instance
+	delete(keys, domain.ProviderOpenAI)
+	providers[3].Enabled = false
+	report = c.Check(context.Background())
+	if report.Status != Degraded || report.Dependencies[DependencyProviders].Status != StatusDown {
+		t.Fatalf("expected degraded without a usable provider, got %+v", report)
+	}
+
+	dispatcherUp = false
+	if report = c.Check(context.Background()); report.Status != NotReady {
+		t.Fatalf("expected not ready with the dispatcher down, got %s", report.Status)
+	}
+}
+
+func TestReplicas(t *testing.T) {
+	states := []domain.ReplicaState{
+		{Name: "replica-1", Usable: true},
+		{Name: "replica-2", Usable: false, Lag: time.Minute, Error: "1m0s behind the primary"},
+	}
+	check := Replicas(func() []domain.ReplicaState { return states })
+
+	result := check(context.Background())
+	if result.Status != StatusDegraded || result.Details["replica-1"] != StatusOK || result.Details["replica-2"] != "1m0s behind the primary" {
+		t.Fatalf("expected degraded with one replica lagging, got %+v", result)
+	}
+
+	states[1].Usable = true
+	if result = check(context.Background()); result.Status != StatusOK {
+		t.Fatalf("expected ok with every replica usable, got %+v", result)
+	}
+}
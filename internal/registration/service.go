@@ -0,0 +1,350 @@
+// Package registration implements self-service account registration: people
+// submit a request, which an admin approves (creating their user) or rejects.
+package registration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+	"modelgate/internal/email"
+	"modelgate/internal/passwords"
+)
+
+var (
+	ErrDisabled        = errors.New("self-service registration is disabled")
+	ErrNotFound        = errors.New("registration request not found")
+	ErrAlreadyReviewed = errors.New("registration request has already been reviewed")
+	ErrEmailTaken      = errors.New("an account or pending request already exists for this email")
+	ErrQueueFull       = errors.New("too many registration requests are awaiting review; try again later")
+)
+
+// Store is the persistence used by the service (implemented by postgres.TenantStore)
+type Store interface {
+	CreateRegistrationRequest(ctx context.Context, r *domain.RegistrationRequest) (bool, error)
+	GetRegistrationRequest(ctx context.Context, id string) (*domain.RegistrationRequest, error)
+	ListRegistrationRequests(ctx context.Context, status string) ([]*domain.RegistrationRequest, error)
+	CountPendingRegistrations(ctx context.Context) (int, error)
+	ReviewRegistrationRequest(ctx context.Context, id, status, reason, userRole, reviewedBy string) (bool, error)
+	ReleaseRegistrationRequest(ctx context.Context, id string) error
+	UserExists(ctx context.Context, email string) (bool, error)
+	CreateRegisteredUser(ctx context.Context, r *domain.RegistrationRequest, role, createdBy, createdByEmail string) (string, error)
+	ListAdminEmails(ctx context.Context) ([]string, error)
+}
+
+// Service runs the registration workflow
+type Service struct {
+	store      Store
+	cfg        config.RegistrationConfig
+	sender     email.Sender // nil when email is disabled
+	httpClient *http.Client
+	passwords  *passwords.Policy
+}
+
+// NewService creates a registration service. Without a sender, notifications
+// go only to the configured webhook.
+func NewService(store Store, cfg config.RegistrationConfig, sender email.Sender) *Service {
+	return &Service{
+		store:      store,
+		cfg:        cfg,
+		sender:     sender,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		passwords:  passwords.NewPolicy(config.Default().Passwords),
+	}
+}
+
+// SetPasswordPolicy sets the policy submitted passwords are held to and hashed with
+func (s *Service) SetPasswordPolicy(policy *passwords.Policy) {
+	s.passwords = policy
+}
+
+// Enabled reports whether new requests are accepted
+func (s *Service) Enabled() bool {
+	return s.cfg.Enabled
+}
+
+// SubmitInput is a registration request as submitted
+type SubmitInput struct {
+	OrganizationName  string
+	OrganizationEmail string
+	AdminName         string
+	AdminEmail        string
+	Password          string
+	PreferredTier     domain.TenantTier
+}
+
+// InvalidError reports a registration request that failed validation
+type InvalidError struct {
+	Reason string
+}
+
+func (e *InvalidError) Error() string {
+	return "invalid registration request: " + e.Reason
+}
+
+// Submit validates and queues a registration request, and notifies admins
+func (s *Service) Submit(ctx context.Context, in SubmitInput) (*domain.RegistrationRequest, error) {
+	if !s.cfg.Enabled {
+		return nil, ErrDisabled
+	}
+	r, err := newRequest(in, s.passwords)
+	if err != nil {
+		return nil, err
+	}
+
+	if exists, err := s.store.UserExists(ctx, r.AdminEmail); err != nil {
+		return nil, fmt.Errorf("check user: %w", err)
+	} else if exists {
+		return nil, ErrEmailTaken
+	}
+	if s.cfg.MaxPending > 0 {
+		pending, err := s.store.CountPendingRegistrations(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("count pending registrations: %w", err)
+		}
+		if pending >= s.cfg.MaxPending {
+			return nil, ErrQueueFull
+		}
+	}
+
+	created, err := s.store.CreateRegistrationRequest(ctx, r)
+	if err != nil {
+		return nil, fmt.Errorf("create registration request: %w", err)
+	}
+	if !created {
+		return nil, ErrEmailTaken
+	}
+
+	s.notifyAdmins(ctx, r)
+	s.postWebhook(ctx, "registration.submitted", r)
+	return r, nil
+}
+
+// newRequest validates the input and builds the request to store
+func newRequest(in SubmitInput, policy *passwords.Policy) (*domain.RegistrationRequest, error) {
+	r := &domain.RegistrationRequest{
+		OrganizationName:  strings.TrimSpace(in.OrganizationName),
+		OrganizationEmail: strings.TrimSpace(in.OrganizationEmail),
+		AdminName:         strings.TrimSpace(in.AdminName),
+		AdminEmail:        strings.ToLower(strings.TrimSpace(in.AdminEmail)),
+		PreferredTier:     in.PreferredTier,
+	}
+	switch {
+	case r.OrganizationName == "":
+		return nil, &InvalidError{"organization name is required"}
+	case r.AdminName == "":
+		return nil, &InvalidError{"name is required"}
+	case !validEmail(r.OrganizationEmail):
+		return nil, &InvalidError{"organization email is not a valid address"}
+	case !validEmail(r.AdminEmail):
+		return nil, &InvalidError{"email is not a valid address"}
+	}
+	if err := policy.Check(in.Password, r.AdminEmail, r.AdminName); err != nil {
+		return nil, &InvalidError{err.Error()}
+	}
+
+	r.Slug = slugify(r.OrganizationName)
+	if r.Slug == "" {
+		return nil, &InvalidError{"organization name must contain letters or digits"}
+	}
+	hash, err := policy.Hash(in.Password)
+	if err != nil {
+		return nil, &InvalidError{"password cannot be used"}
+	}
+	r.PasswordHash = hash
+	return r, nil
+}
+
+func validEmail(addr string) bool {
+	parsed, err := mail.ParseAddress(addr)
+	return err == nil && parsed.Address == addr
+}
+
+var nonSlug = regexp.MustCompile(`[^a-z0-9]+`)
+
+func slugify(name string) string {
+	slug := strings.Trim(nonSlug.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if len(slug) > 63 {
+		slug = strings.TrimRight(slug[:63], "-")
+	}
+	return slug
+}
+
+// List returns registration requests with the status ("" for all), oldest first
+func (s *Service) List(ctx context.Context, status string) ([]*domain.RegistrationRequest, error) {
+	return s.store.ListRegistrationRequests(ctx, status)
+}
+
+// Get returns a registration request, or nil if it does not exist
+func (s *Service) Get(ctx context.Context, id string) (*domain.RegistrationRequest, error) {
+	return s.store.GetRegistrationRequest(ctx, id)
+}
+
+// Approve creates the user of a pending request with the role (the
+// configured default when empty) and notifies the requester. reviewer
+// identifies the admin for the request's record.
+func (s *Service) Approve(ctx context.Context, id, role, reviewerID, reviewerEmail string) (*domain.RegistrationRequest, error) {
+	if role == "" {
+		role = s.cfg.DefaultRole
+	}
+	if !validRole(role) {
+		return nil, &InvalidError{fmt.Sprintf("role %q must be admin, member or viewer", role)}
+	}
+	r, err := s.claim(ctx, id, domain.RegistrationApproved, "", role, reviewerEmail)
+	if err != nil {
+		return nil, err
+	}
+
+	userID, err := s.store.CreateRegisteredUser(ctx, r, role, reviewerID, reviewerEmail)
+	if err == nil && userID == "" {
+		err = ErrEmailTaken
+	}
+	if err != nil {
+		// Put the request back so the decision can be retried or changed
+		if rerr := s.store.ReleaseRegistrationRequest(ctx, id); rerr != nil {
+			slog.Error("Failed to release registration request", "id", id, "error", rerr)
+		}
+		if errors.Is(err, ErrEmailTaken) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("create user: %w", err)
+	}
+
+	s.notifyRequester(ctx, r)
+	s.postWebhook(ctx, "registration.approved", r)
+	return r, nil
+}
+
+// Reject rejects a pending request with a reason, which is sent to the requester
+func (s *Service) Reject(ctx context.Context, id, reason, reviewerEmail string) (*domain.RegistrationRequest, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, &InvalidError{"a rejection reason is required"}
+	}
+	r, err := s.claim(ctx, id, domain.RegistrationRejected, reason, "", reviewerEmail)
+	if err != nil {
+		return nil, err
+	}
+	s.notifyRequester(ctx, r)
+	s.postWebhook(ctx, "registration.rejected", r)
+	return r, nil
+}
+
+// claim records the decision on a pending request, so two admins can't
+// decide the same request, and returns the updated request
+func (s *Service) claim(ctx context.Context, id, status, reason, role, reviewer string) (*domain.RegistrationRequest, error) {
+	r, err := s.store.GetRegistrationRequest(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("get registration request: %w", err)
+	}
+	if r == nil {
+		return nil, ErrNotFound
+	}
+	ok, err := s.store.ReviewRegistrationRequest(ctx, id, status, reason, role, reviewer)
+	if err != nil {
+		return nil, fmt.Errorf("review registration request: %w", err)
+	}
+	if !ok {
+		return nil, ErrAlreadyReviewed
+	}
+	now := time.Now()
+	r.Status, r.RejectionReason, r.UserRole = status, reason, role
+	r.ReviewedAt, r.ReviewedBy, r.UpdatedAt = &now, reviewer, now
+	return r, nil
+}
+
+func validRole(role string) bool {
+	return role == "admin" || role == "member" || role == "viewer"
+}
+
+// notifyAdmins emails the configured addresses, or every admin, about a new request
+func (s *Service) notifyAdmins(ctx context.Context, r *domain.RegistrationRequest) {
+	if s.sender == nil {
+		return
+	}
+	to := s.cfg.NotifyEmails
+	if len(to) == 0 {
+		admins, err := s.store.ListAdminEmails(ctx)
+		if err != nil {
+			slog.Error("Failed to list admins for registration notification", "error", err)
+			return
+		}
+		to = admins
+	}
+	if len(to) == 0 {
+		return
+	}
+	s.send(ctx, &email.Message{
+		To:      to,
+		Subject: fmt.Sprintf("New registration request from %s", r.OrganizationName),
+		Text: fmt.Sprintf("%s <%s> of %s has requested an account. Review it under Registration Requests in the admin portal.",
+			r.AdminName, r.AdminEmail, r.OrganizationName),
+	})
+}
+
+// notifyRequester emails the requester the decision on their request
+func (s *Service) notifyRequester(ctx context.Context, r *domain.RegistrationRequest) {
+	if s.sender == nil {
+		return
+	}
+	msg := &email.Message{To: []string{r.AdminEmail}}
+	if r.Status == domain.RegistrationApproved {
+		msg.Subject = "Your registration has been approved"
+		msg.Text = fmt.Sprintf("Hi %s,\n\nYour account has been approved. You can now sign in as %s with the password you registered with.",
+			r.AdminName, r.AdminEmail)
+	} else {
+		msg.Subject = "Your registration has been declined"
+		msg.Text = fmt.Sprintf("Hi %s,\n\nYour registration request for %s was declined: %s",
+			r.AdminName, r.OrganizationName, r.RejectionReason)
+	}
+	s.send(ctx, msg)
+}
+
+func (s *Service) send(ctx context.Context, msg *email.Message) {
+	if err := s.sender.Send(ctx, msg); err != nil {
+		slog.Error("Failed to send registration email", "subject", msg.Subject, "error", err)
+	}
+}
+
+// registrationEvent is the payload posted to the notification webhook
+type registrationEvent struct {
+	Event   string                      `json:"event"`
+	Request *domain.RegistrationRequest `json:"request"`
+}
+
+// postWebhook posts an event to the notification webhook. Failures are
+// logged; the event is not retried.
+func (s *Service) postWebhook(ctx context.Context, event string, r *domain.RegistrationRequest) {
+	if s.cfg.NotifyWebhook == "" {
+		return
+	}
+	body, err := json.Marshal(registrationEvent{Event: event, Request: r})
+	if err != nil {
+		return
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.NotifyWebhook, bytes.NewReader(body))
+	if err != nil {
+		slog.Error("Failed to build registration webhook", "error", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		slog.Error("Failed to send registration webhook", "event", event, "error", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		slog.Error("Registration webhook failed", "event", event, "status", resp.Status)
+	}
+}
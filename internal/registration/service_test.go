@@ -0,0 +1,155 @@
+package registration
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+	"modelgate/internal/email"
+)
+
+type fakeStore struct {
+	requests map[string]*domain.RegistrationRequest
+	users    map[string]string // Email -> role
+}
+
+func (f *fakeStore) CreateRegistrationRequest(ctx context.Context, r *domain.RegistrationRequest) (bool, error) {
+	for _, existing := range f.requests {
+		if existing.Status == domain.RegistrationPending && existing.AdminEmail == r.AdminEmail {
+			return false, nil
+		}
+	}
+	r.ID = "req-" + r.Slug
+	r.Status = domain.RegistrationPending
+	stored := *r
+	f.requests[r.ID] = &stored
+	return true, nil
+}
+
+func (f *fakeStore) GetRegistrationRequest(ctx context.Context, id string) (*domain.RegistrationRequest, error) {
+	if r, ok := f.requests[id]; ok {
+		copied := *r
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (f *fakeStore) ListRegistrationRequests(ctx context.Context, status string) ([]*domain.RegistrationRequest, error) {
+	return nil, nil
+}
+
+func (f *fakeStore) CountPendingRegistrations(ctx context.Context) (int, error) {
+	n := 0
+	for _, r := range f.requests {
+		if r.Status == domain.RegistrationPending {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (f *fakeStore) ReviewRegistrationRequest(ctx context.Context, id, status, reason, userRole, reviewedBy string) (bool, error) {
+	r, ok := f.requests[id]
+	if !ok || r.Status != domain.RegistrationPending {
+		return false, nil
+	}
+	r.Status, r.RejectionReason, r.UserRole, r.ReviewedBy = status, reason, userRole, reviewedBy
+	return true, nil
+}
+
+func (f *fakeStore) ReleaseRegistrationRequest(ctx context.Context, id string) error {
+	f.requests[id].Status = domain.RegistrationPending
+	return nil
+}
+
+func (f *fakeStore) UserExists(ctx context.Context, email string) (bool, error) {
+	_, ok := f.users[email]
+	return ok, nil
+}
+
+func (f *fakeStore) CreateRegisteredUser(ctx context.Context, r *domain.RegistrationRequest, role, createdBy, createdByEmail string) (string, error) {
+	if _, ok := f.users[r.AdminEmail]; ok {
+		return "", nil
+	}
+	f.users[r.AdminEmail] = role
+	return "user-1", nil
+}
+
+func (f *fakeStore) ListAdminEmails(ctx context.Context) ([]string, error) {
+	return []string{"root@example.com"}, nil
+}
+
+type fakeSender struct{ sent []*email.Message }
+
+func (s *fakeSender) Send(ctx context.Context, msg *email.Message) error {
+	s.sent = append(s.sent, msg)
+	return nil
+}
+
+func TestRegistrationWorkflow(t *testing.T) {
+	ctx := context.Background()
+	store := &fakeStore{requests: map[string]*domain.RegistrationRequest{}, users: map[string]string{}}
+	sender := &fakeSender{}
+	svc := NewService(store, config.RegistrationConfig{Enabled: true, MaxPending: 2, DefaultRole: "member"}, sender)
+
+	in := SubmitInput{
+		OrganizationName:  "Acme Corp.",
+		OrganizationEmail: "it@acme.example",
+		AdminName:         "Sam",
+		AdminEmail:        "Sam@Acme.example",
+		Password:          "short",
+	}
+	var invalid *InvalidError
+	if _, err := svc.Submit(ctx, in); !errors.As(err, &invalid) {
+		t.Fatalf("expected a short password to be rejected, got %v", err)
+	}
+
+	in.Password = "correct horse"
+	req, err := svc.Submit(ctx, in)
+	if err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+	if req.Slug != "acme-corp" || req.AdminEmail != "sam@acme.example" || req.PasswordHash == "" {
+		t.Fatalf("unexpected request %+v", req)
+	}
+	if len(sender.sent) != 1 || sender.sent[0].To[0] != "root@example.com" {
+		t.Fatalf("expected admins to be notified, got %+v", sender.sent)
+	}
+	if _, err := svc.Submit(ctx, in); !errors.Is(err, ErrEmailTaken) {
+		t.Fatalf("expected a duplicate pending request to be refused, got %v", err)
+	}
+
+	if _, err := svc.Approve(ctx, req.ID, "owner", "u1", "root@example.com"); !errors.As(err, &invalid) {
+		t.Fatalf("expected an unknown role to be refused, got %v", err)
+	}
+	approved, err := svc.Approve(ctx, req.ID, "", "u1", "root@example.com")
+	if err != nil {
+		t.Fatalf("Approve failed: %v", err)
+	}
+	if approved.Status != domain.RegistrationApproved || store.users["sam@acme.example"] != "member" {
+		t.Fatalf("expected the user to be created with the default role, got %+v", approved)
+	}
+	if last := sender.sent[len(sender.sent)-1]; last.To[0] != "sam@acme.example" {
+		t.Fatalf("expected the requester to be notified, got %+v", last)
+	}
+	if _, err := svc.Reject(ctx, req.ID, "duplicate", "root@example.com"); !errors.Is(err, ErrAlreadyReviewed) {
+		t.Fatalf("expected a reviewed request to stay decided, got %v", err)
+	}
+
+	// An approval that can't create the user puts the request back in the queue
+	in.AdminEmail = "taken@acme.example"
+	in.OrganizationName = "Acme Two"
+	req, err = svc.Submit(ctx, in)
+	if err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+	store.users["taken@acme.example"] = "viewer"
+	if _, err := svc.Approve(ctx, req.ID, "viewer", "u1", "root@example.com"); !errors.Is(err, ErrEmailTaken) {
+		t.Fatalf("expected the taken email to fail approval, got %v", err)
+	}
+	if store.requests[req.ID].Status != domain.RegistrationPending {
+		t.Fatalf("expected the request to be pending again, got %q", store.requests[req.ID].Status)
+	}
+}
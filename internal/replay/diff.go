@@ -0,0 +1,96 @@
+package replay
+
+import "strings"
+
+// Diff row kinds
+const (
+	DiffEqual   = "equal"
+	DiffChanged = "changed"
+	DiffRemoved = "removed" // Only on the original side
+	DiffAdded   = "added"   // Only on the replay side
+)
+
+// maxDiffLines bounds the LCS table; longer responses are compared as a whole
+const maxDiffLines = 2000
+
+// DiffRow is one row of a side-by-side, line-based diff
+type DiffRow struct {
+	Kind  string
+	Left  *string // Original line; nil when the row was added
+	Right *string // Replayed line; nil when the row was removed
+}
+
+// Diff compares two texts line by line and returns side-by-side rows. Runs of
+// removed lines followed by added lines are paired up as changed rows.
+func Diff(original, replayed string) []DiffRow {
+	a, b := splitLines(original), splitLines(replayed)
+	if len(a) > maxDiffLines || len(b) > maxDiffLines {
+		if original == replayed {
+			return []DiffRow{{Kind: DiffEqual, Left: &original, Right: &replayed}}
+		}
+		return []DiffRow{{Kind: DiffChanged, Left: &original, Right: &replayed}}
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var rows []DiffRow
+	var removed, added []string
+	flush := func() {
+		n := max(len(removed), len(added))
+		for k := 0; k < n; k++ {
+			row := DiffRow{Kind: DiffChanged}
+			if k < len(removed) {
+				row.Left = &removed[k]
+			}
+			if k < len(added) {
+				row.Right = &added[k]
+			}
+			if row.Left == nil {
+				row.Kind = DiffAdded
+			} else if row.Right == nil {
+				row.Kind = DiffRemoved
+			}
+			rows = append(rows, row)
+		}
+		removed, added = nil, nil
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			flush()
+			rows = append(rows, DiffRow{Kind: DiffEqual, Left: &a[i], Right: &b[j]})
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			removed = append(removed, a[i])
+			i++
+		default:
+			added = append(added, b[j])
+			j++
+		}
+	}
+	flush()
+	return rows
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
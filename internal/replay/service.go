@@ -0,0 +1,147 @@
+// Package replay re-runs logged requests through the gateway and compares the
+// new response with the original one.
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"modelgate/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// ErrNotFound is returned when the usage record does not exist
+var ErrNotFound = errors.New("request log not found")
+
+// ErrNotCaptured is returned when the request's content was not captured
+var ErrNotCaptured = errors.New("request content was not captured; enable [replay] capture_content to replay new requests")
+
+// Store is the persistence used by the service (implemented by postgres.Store)
+type Store interface {
+	GetUsageRecord(ctx context.Context, id string) (*domain.UsageRecord, error)
+	OpenCapturedContent(ctx context.Context, record *domain.UsageRecord) error
+}
+
+// Gateway sends the replayed request (implemented by gateway.Service)
+type Gateway interface {
+	ChatComplete(ctx context.Context, req *domain.ChatRequest) (*domain.ChatResponse, error)
+}
+
+// Side is one of the two responses being compared
+type Side struct {
+	Model        string
+	Response     string
+	Success      bool
+	Error        string
+	InputTokens  int64
+	OutputTokens int64
+	CostUSD      float64
+	LatencyMs    int64
+}
+
+// Result is the outcome of a replay
+type Result struct {
+	RecordID  string
+	RequestID string // Request ID of the replay
+	Original  Side
+	Replay    Side
+	Diff      []DiffRow
+	Identical bool
+}
+
+// Service replays captured requests
+type Service struct {
+	store   Store
+	gateway Gateway
+}
+
+// NewService creates a new replay service
+func NewService(store Store, gateway Gateway) *Service {
+	return &Service{store: store, gateway: gateway}
+}
+
+// Request reconstructs the request captured with a usage record
+func (s *Service) Request(ctx context.Context, recordID string) (*domain.UsageRecord, *domain.ChatRequest, error) {
+	record, err := s.store.GetUsageRecord(ctx, recordID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get usage record: %w", err)
+	}
+	if record == nil {
+		return nil, nil, ErrNotFound
+	}
+	if err := s.store.OpenCapturedContent(ctx, record); err != nil {
+		return nil, nil, fmt.Errorf("decrypt captured content: %w", err)
+	}
+	captured, ok := record.Metadata["request"]
+	if !ok || captured == nil {
+		return nil, nil, ErrNotCaptured
+	}
+	body, err := json.Marshal(captured)
+	if err != nil {
+		return nil, nil, fmt.Errorf("decode captured request: %w", err)
+	}
+	var req domain.ChatRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, nil, fmt.Errorf("decode captured request: %w", err)
+	}
+	return record, &req, nil
+}
+
+// Replay re-sends a captured request, optionally to another model, and diffs
+// the new response against the original. The replay is recorded as its own
+// usage record that points back to the original.
+func (s *Service) Replay(ctx context.Context, recordID, model string) (*Result, error) {
+	record, req, err := s.Request(ctx, recordID)
+	if err != nil {
+		return nil, err
+	}
+
+	originalText, _ := record.Metadata["response"].(string)
+	result := &Result{
+		RecordID: record.ID,
+		Original: Side{
+			Model:        record.Model,
+			Response:     originalText,
+			Success:      record.Success,
+			Error:        strings.TrimSpace(record.ErrorCode + " " + record.ErrorMessage),
+			InputTokens:  record.InputTokens,
+			OutputTokens: record.OutputTokens,
+			CostUSD:      record.CostUSD,
+			LatencyMs:    record.LatencyMs,
+		},
+	}
+
+	if model = strings.TrimSpace(model); model != "" {
+		req.Model = model
+	}
+	req.RequestID = "replay-" + uuid.New().String()
+	req.ReplayOf = record.ID
+	req.Streaming = false
+	result.RequestID = req.RequestID
+
+	resp, err := s.gateway.ChatComplete(ctx, req)
+	result.Replay.Model = req.Model
+	if err != nil {
+		result.Replay.Error = err.Error()
+	} else {
+		result.Replay.Success = true
+		result.Replay.Response = resp.Content
+		result.Replay.CostUSD = resp.CostUSD
+		result.Replay.LatencyMs = resp.LatencyMs
+		if resp.Model != "" {
+			result.Replay.Model = resp.Model
+		}
+		if resp.Usage != nil {
+			result.Replay.InputTokens = int64(resp.Usage.PromptTokens)
+			result.Replay.OutputTokens = int64(resp.Usage.CompletionTokens)
+		}
+	}
+
+	result.Diff = Diff(result.Original.Response, result.Replay.Response)
+	result.Identical = result.Original.Response == result.Replay.Response
+	return result, nil
+}
@@ -0,0 +1,93 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"modelgate/internal/domain"
+)
+
+type fakeStore map[string]*domain.UsageRecord
+
+func (f fakeStore) GetUsageRecord(ctx context.Context, id string) (*domain.UsageRecord, error) {
+	return f[id], nil
+}
+
+func (f fakeStore) OpenCapturedContent(ctx context.Context, record *domain.UsageRecord) error {
+	return nil
+}
+
+type fakeGateway struct{ got *domain.ChatRequest }
+
+func (g *fakeGateway) ChatComplete(ctx context.Context, req *domain.ChatRequest) (*domain.ChatResponse, error) {
+	g.got = req
+	return &domain.ChatResponse{
+		Content: "Paris is the capital.\nIt is in France.\nPopulation: 2.1M",
+		Model:   req.Model,
+		Usage:   &domain.UsageEvent{PromptTokens: 12, CompletionTokens: 9},
+	}, nil
+}
+
+// captured round-trips a request the way recordUsage stores it in metadata
+func captured(t *testing.T, req *domain.ChatRequest) any {
+	body, _ := json.Marshal(req)
+	var out any
+	if err := json.Unmarshal(body, &out); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestReplayWithModelOverride(t *testing.T) {
+	original := &domain.ChatRequest{
+		Model:    "openai/gpt-4o",
+		Messages: []domain.Message{{Role: "user", Content: []domain.ContentBlock{{Type: "text", Text: "Capital of France?"}}}},
+		RoleID:   "role-1",
+		APIKeyID: "key-1",
+	}
+	store := fakeStore{
+		"rec-1": {
+			ID:      "rec-1",
+			Model:   "openai/gpt-4o",
+			Success: true,
+			Metadata: map[string]any{
+				"request":  captured(t, original),
+				"response": "Paris is the capital.\nIt is in France.",
+			},
+		},
+		"rec-2": {ID: "rec-2", Metadata: map[string]any{"prompt": "hi"}},
+	}
+	gw := &fakeGateway{}
+	svc := NewService(store, gw)
+
+	result, err := svc.Replay(context.Background(), "rec-1", "anthropic/claude-3-5-haiku")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gw.got.Model != "anthropic/claude-3-5-haiku" || gw.got.ReplayOf != "rec-1" || gw.got.RoleID != "role-1" {
+		t.Fatalf("unexpected replayed request: %+v", gw.got)
+	}
+	if gw.got.Messages[0].Content[0].Text != "Capital of France?" {
+		t.Fatalf("messages were not reconstructed: %+v", gw.got.Messages)
+	}
+	if result.Identical || len(result.Diff) != 3 {
+		t.Fatalf("unexpected diff: %+v", result.Diff)
+	}
+	if result.Diff[0].Kind != DiffEqual || result.Diff[2].Kind != DiffAdded || result.Diff[2].Left != nil {
+		t.Fatalf("unexpected diff rows: %+v", result.Diff)
+	}
+
+	if _, err := svc.Replay(context.Background(), "rec-2", ""); !errors.Is(err, ErrNotCaptured) {
+		t.Fatalf("expected ErrNotCaptured, got %v", err)
+	}
+	if _, err := svc.Replay(context.Background(), "missing", ""); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	rows := Diff("a\nb\nc", "a\nx\nc")
+	if len(rows) != 3 || rows[1].Kind != DiffChanged || *rows[1].Left != "b" || *rows[1].Right != "x" {
+		t.Fatalf("expected a changed middle row, got %+v", rows)
+	}
+}
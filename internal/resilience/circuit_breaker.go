@@ -19,10 +19,14 @@ const (
 
 // CircuitBreaker implements circuit breaker pattern for provider failures
 type CircuitBreaker struct {
-	db    *sql.DB
-	cache sync.Map // tenant:provider -> *CircuitStatus
+	db           *sql.DB
+	cache        sync.Map // tenant:provider -> *CircuitStatus
+	onTransition TransitionHook
 }
 
+// TransitionHook is called after a circuit changes state
+type TransitionHook func(tenantID, provider string, from, to CircuitState)
+
 // CircuitStatus represents the current status of a circuit
 type CircuitStatus struct {
 	State                CircuitState
@@ -37,6 +41,12 @@ func NewCircuitBreaker(db *sql.DB) *CircuitBreaker {
 	return &CircuitBreaker{db: db}
 }
 
+// SetTransitionHook registers a function called on every state change, e.g.
+// to export transitions as metrics. Call it before the breaker is used.
+func (cb *CircuitBreaker) SetTransitionHook(hook TransitionHook) {
+	cb.onTransition = hook
+}
+
 // AllowRequest checks if request is allowed based on circuit state
 func (cb *CircuitBreaker) AllowRequest(ctx context.Context, tenantID, provider string, threshold, timeoutSec int) (bool, error) {
 	status, err := cb.getStatus(ctx, tenantID, provider)
@@ -156,36 +166,41 @@ func (cb *CircuitBreaker) getStatus(ctx context.Context, tenantID, provider stri
 
 // transitionToOpen transitions circuit to open state
 func (cb *CircuitBreaker) transitionToOpen(ctx context.Context, tenantID, provider string) {
-	query := `
-		UPDATE circuit_breaker_state
-		SET state = $1, opened_at = NOW(), last_state_change_at = NOW()
-		WHERE tenant_id = $2 AND provider = $3
-	`
-
-	_, _ = cb.db.ExecContext(ctx, query, StateOpen, tenantID, provider)
-	cb.cache.Delete(tenantID + ":" + provider)
+	cb.transition(ctx, tenantID, provider, StateOpen, "opened_at = NOW()")
 }
 
 // transitionToHalfOpen transitions circuit to half-open state
 func (cb *CircuitBreaker) transitionToHalfOpen(ctx context.Context, tenantID, provider string) {
-	query := `
-		UPDATE circuit_breaker_state
-		SET state = $1, last_state_change_at = NOW()
-		WHERE tenant_id = $2 AND provider = $3
-	`
-
-	_, _ = cb.db.ExecContext(ctx, query, StateHalfOpen, tenantID, provider)
-	cb.cache.Delete(tenantID + ":" + provider)
+	cb.transition(ctx, tenantID, provider, StateHalfOpen, "")
 }
 
 // transitionToClosed transitions circuit to closed state
 func (cb *CircuitBreaker) transitionToClosed(ctx context.Context, tenantID, provider string) {
+	cb.transition(ctx, tenantID, provider, StateClosed, "failure_count = 0, consecutive_successes = 0")
+}
+
+// transition sets a circuit's state, applying the extra column assignments,
+// and reports the change to the transition hook
+func (cb *CircuitBreaker) transition(ctx context.Context, tenantID, provider string, to CircuitState, set string) {
+	if set != "" {
+		set += ", "
+	}
 	query := `
-		UPDATE circuit_breaker_state
-		SET state = $1, failure_count = 0, consecutive_successes = 0, last_state_change_at = NOW()
-		WHERE tenant_id = $2 AND provider = $3
+		UPDATE circuit_breaker_state c
+		SET state = $1, ` + set + `last_state_change_at = NOW()
+		FROM (
+			SELECT state FROM circuit_breaker_state
+			WHERE tenant_id = $2 AND provider = $3
+			FOR UPDATE
+		) old
+		WHERE c.tenant_id = $2 AND c.provider = $3
+		RETURNING old.state
 	`
 
-	_, _ = cb.db.ExecContext(ctx, query, StateClosed, tenantID, provider)
+	var from string
+	err := cb.db.QueryRowContext(ctx, query, to, tenantID, provider).Scan(&from)
 	cb.cache.Delete(tenantID + ":" + provider)
+	if err == nil && CircuitState(from) != to && cb.onTransition != nil {
+		cb.onTransition(tenantID, provider, CircuitState(from), to)
+	}
 }
@@ -4,58 +4,76 @@ package responses
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"modelgate/internal/config"
 	"modelgate/internal/domain"
 	"modelgate/internal/provider"
 	"modelgate/internal/storage/postgres"
+	"modelgate/internal/telemetry"
 
 	"github.com/google/uuid"
 )
 
 // Service handles structured output requests for the /v1/responses endpoint
 type Service struct {
-	config          *config.Config
+	config          atomic.Pointer[config.Config]
 	providerManager *provider.Manager
 	pgStore         *postgres.Store
 	validator       *SchemaValidator
-	retryConfig     RetryConfig
+	metrics         *telemetry.Metrics
 }
 
-// RetryConfig defines retry behavior for non-native providers
-type RetryConfig struct {
-	MaxRetries         int
-	RetryableProviders map[domain.Provider]bool
+// Outcomes of validated structured output requests
+const (
+	OutcomeValid    = "valid"    // The first output passed validation
+	OutcomeRepaired = "repaired" // A repaired output passed validation
+	OutcomeFailed   = "failed"   // No output passed validation
+)
+
+// RepairError is returned when output still fails validation after every
+// repair attempt
+type RepairError struct {
+	Attempts int
+	Failures []string // Why each attempt failed validation
+	Err      *ValidationError
+}
+
+func (e *RepairError) Error() string {
+	return fmt.Sprintf("output failed schema validation after %d attempt(s): %v", e.Attempts, e.Err)
+}
+
+func (e *RepairError) Unwrap() error {
+	return e.Err
 }
 
 // NewService creates a new Responses service
-func NewService(cfg *config.Config, providerManager *provider.Manager, pgStore *postgres.Store) *Service {
-	return &Service{
-		config:          cfg,
+func NewService(cfg *config.Config, providerManager *provider.Manager, pgStore *postgres.Store, metrics *telemetry.Metrics) *Service {
+	s := &Service{
 		providerManager: providerManager,
 		pgStore:         pgStore,
 		validator:       NewSchemaValidator(),
-		retryConfig: RetryConfig{
-			MaxRetries: 3,
-			RetryableProviders: map[domain.Provider]bool{
-				domain.ProviderAnthropic: true,
-				domain.ProviderBedrock:   true,
-				domain.ProviderGemini:    true,
-				domain.ProviderMistral:   true,
-				domain.ProviderOllama:    true,
-			},
-		},
+		metrics:         metrics,
 	}
+	s.config.Store(cfg)
+	return s
+}
+
+// SetConfig swaps in a reloaded configuration
+func (s *Service) SetConfig(cfg *config.Config) {
+	s.config.Store(cfg)
 }
 
 // getClientForTenant returns a client for the given tenant and model
 // This loads provider configuration on-demand from the database per session
 // For single-tenant mode, use tenantSlug="default"
 func (s *Service) getClientForTenant(ctx context.Context, tenantID string, tenantSlug string, model string) (domain.LLMClient, error) {
-	providerType, ok := s.config.GetProviderForModel(model)
+	providerType, ok := s.config.Load().GetProviderForModel(model)
 	if !ok {
 		return nil, fmt.Errorf("unknown provider for model: %s", model)
 	}
@@ -119,6 +137,8 @@ func (s *Service) GenerateResponse(ctx context.Context, req *domain.ResponseRequ
 		result, err = s.generateNative(ctx, req, providerClient)
 	case StrategyJSONMode:
 		result, err = s.generateWithJSONMode(ctx, req, providerClient)
+	case StrategyGrammar:
+		result, err = s.generateWithGrammar(ctx, req, providerClient)
 	case StrategyPromptBased:
 		result, err = s.generateWithPrompt(ctx, req, providerClient)
 	default:
@@ -133,7 +153,9 @@ func (s *Service) GenerateResponse(ctx context.Context, req *domain.ResponseRequ
 	if result.Metadata == nil {
 		result.Metadata = &domain.ResponseMetadata{}
 	}
-	result.Metadata.Provider = string(providerClient.Provider())
+	if result.Metadata.Provider == "" {
+		result.Metadata.Provider = string(providerClient.Provider())
+	}
 	result.Metadata.ImplementationMode = string(strategy)
 	result.Metadata.SchemaValidated = true
 
@@ -147,6 +169,7 @@ const (
 	StrategyNative      ProviderStrategy = "native"
 	StrategyJSONMode    ProviderStrategy = "json_mode"
 	StrategyPromptBased ProviderStrategy = "prompt_based"
+	StrategyGrammar     ProviderStrategy = "grammar"
 )
 
 // getProviderStrategy determines which strategy to use for a client
@@ -163,9 +186,13 @@ func (s *Service) getProviderStrategy(client domain.LLMClient) ProviderStrategy
 		// Fallback to JSON mode if native not available
 		return StrategyJSONMode
 
-	case domain.ProviderGroq, domain.ProviderTogether, domain.ProviderCohere:
+	case domain.ProviderGroq, domain.ProviderTogether, domain.ProviderCohere, domain.ProviderOpenRouter, domain.ProviderXAI,
+		domain.ProviderDeepSeek, domain.ProviderHuggingFace:
 		return StrategyJSONMode
 
+	case domain.ProviderFireworks:
+		return StrategyGrammar
+
 	case domain.ProviderGemini:
 		return StrategyJSONMode
 
@@ -188,58 +215,114 @@ func (s *Service) generateNative(ctx context.Context, req *domain.ResponseReques
 
 // generateWithJSONMode uses JSON mode + validation
 func (s *Service) generateWithJSONMode(ctx context.Context, req *domain.ResponseRequest, client domain.LLMClient) (*domain.StructuredResponse, error) {
-	// Convert to chat request with JSON mode enabled
-	chatReq := s.convertToJSONModeRequest(req)
-
-	// Call chat completion (non-streaming)
-	resp, err := client.ChatComplete(ctx, chatReq)
-	if err != nil {
-		return nil, fmt.Errorf("chat completion failed: %w", err)
-	}
+	return s.generateWithRepair(ctx, req, client, s.convertToJSONModeRequest(req))
+}
 
-	// Extract and validate JSON
-	return s.validateAndParse(resp, req)
+// generateWithGrammar sends the schema as a json_schema response_format, which
+// Fireworks compiles to a grammar that constrains decoding, so the output
+// matches the schema's structure; validation still checks the rest
+func (s *Service) generateWithGrammar(ctx context.Context, req *domain.ResponseRequest, client domain.LLMClient) (*domain.StructuredResponse, error) {
+	return s.generateWithRepair(ctx, req, client, s.convertToGrammarRequest(req))
 }
 
-// generateWithPrompt uses prompt engineering + validation + retry
+// generateWithPrompt uses prompt engineering + validation
 func (s *Service) generateWithPrompt(ctx context.Context, req *domain.ResponseRequest, client domain.LLMClient) (*domain.StructuredResponse, error) {
-	maxRetries := s.retryConfig.MaxRetries
-	if !s.retryConfig.RetryableProviders[client.Provider()] {
-		maxRetries = 1
-	}
+	return s.generateWithRepair(ctx, req, client, s.convertToPromptBasedRequest(req))
+}
 
-	var lastErr error
-	for attempt := 0; attempt < maxRetries; attempt++ {
-		// Inject schema into system prompt
-		chatReq := s.convertToPromptBasedRequest(req)
+// generateWithRepair sends chatReq and validates the output against the
+// response schema. Output that fails validation is sent back with the
+// validation errors for the model to repair, up to the configured number of
+// retries. The last retry goes to the repair model when one is configured.
+func (s *Service) generateWithRepair(ctx context.Context, req *domain.ResponseRequest, client domain.LLMClient, chatReq *domain.ChatRequest) (*domain.StructuredResponse, error) {
+	cfg := s.config.Load().Responses
+	metadata := &domain.ResponseMetadata{}
+	var usage domain.ResponseUsage
 
-		// Call chat completion (non-streaming)
+	for attempt := 0; ; attempt++ {
 		resp, err := client.ChatComplete(ctx, chatReq)
 		if err != nil {
-			lastErr = fmt.Errorf("chat completion failed: %w", err)
-			continue
+			return nil, fmt.Errorf("chat completion failed: %w", err)
 		}
+		usage.PromptTokens += int(resp.Usage.PromptTokens)
+		usage.CompletionTokens += int(resp.Usage.CompletionTokens)
+		usage.TotalTokens += int(resp.Usage.TotalTokens)
 
-		// Try to validate
+		providerName := string(client.Provider())
 		result, err := s.validateAndParse(resp, req)
 		if err == nil {
-			if result.Metadata == nil {
-				result.Metadata = &domain.ResponseMetadata{}
+			outcome := OutcomeValid
+			if attempt > 0 {
+				outcome = OutcomeRepaired
 			}
-			result.Metadata.RetryCount = attempt
+			s.recordResult(providerName, req.Model, outcome)
+			metadata.Provider = providerName
+			metadata.RetryCount = attempt
+			result.Metadata = metadata
+			result.Usage = usage
 			return result, nil
 		}
 
-		lastErr = err
+		var invalid *ValidationError
+		if !errors.As(err, &invalid) {
+			return nil, err
+		}
+		metadata.ValidationFailures = append(metadata.ValidationFailures, invalid.Reason)
+		if attempt >= cfg.ValidationRetries {
+			s.recordResult(providerName, req.Model, OutcomeFailed)
+			return nil, &RepairError{Attempts: attempt + 1, Failures: metadata.ValidationFailures, Err: invalid}
+		}
+		if s.metrics != nil {
+			s.metrics.RecordStructuredOutputRetry(providerName, chatReq.Model, invalid.Reason)
+		}
+		slog.Debug("Structured output failed validation, asking for repair",
+			"model", chatReq.Model, "attempt", attempt+1, "reason", invalid.Reason)
+
+		chatReq = repairRequest(chatReq, resp.Content, req.ResponseSchema.Name, invalid)
+		if attempt+1 == cfg.ValidationRetries && cfg.RepairModel != "" && cfg.RepairModel != chatReq.Model {
+			repairClient, err := s.getClientForTenant(ctx, "", "", cfg.RepairModel)
+			if err != nil {
+				slog.Warn("Repair model unavailable, repairing with the request's model", "repair_model", cfg.RepairModel, "error", err)
+				continue
+			}
+			if s.getProviderStrategy(repairClient) == StrategyPromptBased {
+				chatReq.AdditionalParams = nil // JSON mode isn't understood there
+			}
+			client = repairClient
+			chatReq.Model = cfg.RepairModel
+			metadata.RepairModel = cfg.RepairModel
+		}
 	}
+}
 
-	return nil, fmt.Errorf("failed after %d attempts: %w", maxRetries, lastErr)
+// repairRequest returns chatReq continued with the rejected output and a
+// request to correct it
+func repairRequest(chatReq *domain.ChatRequest, output, schemaName string, invalid *ValidationError) *domain.ChatRequest {
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "That response failed validation against the %s schema:\n", schemaName)
+	for _, e := range invalid.Errors {
+		fmt.Fprintf(&prompt, "- %s\n", e)
+	}
+	prompt.WriteString("Respond again with ONLY the corrected JSON object.")
+
+	next := *chatReq
+	next.Messages = append(append([]domain.Message(nil), chatReq.Messages...),
+		domain.Message{Role: "assistant", Content: []domain.ContentBlock{{Type: "text", Text: output}}},
+		domain.Message{Role: "user", Content: []domain.ContentBlock{{Type: "text", Text: prompt.String()}}},
+	)
+	return &next
+}
+
+func (s *Service) recordResult(provider, model, outcome string) {
+	if s.metrics != nil {
+		s.metrics.RecordStructuredOutputResult(provider, model, outcome)
+	}
 }
 
 // convertToJSONModeRequest creates a chat request with JSON mode
 func (s *Service) convertToJSONModeRequest(req *domain.ResponseRequest) *domain.ChatRequest {
 	// Inject schema guidance in system prompt
-	systemPrompt := s.buildSchemaPrompt(req.ResponseSchema)
+	systemPrompt := SchemaPrompt(req.ResponseSchema)
 
 	// Check if there's already a system message
 	messages := req.Messages
@@ -256,6 +339,7 @@ func (s *Service) convertToJSONModeRequest(req *domain.ResponseRequest) *domain.
 		Messages:     messages,
 		SystemPrompt: systemPrompt,
 		Temperature:  req.Temperature,
+		TopP:         req.TopP,
 		MaxTokens:    req.MaxTokens,
 		RequestID:    req.RequestID,
 		APIKeyID:     req.APIKeyID,
@@ -267,9 +351,20 @@ func (s *Service) convertToJSONModeRequest(req *domain.ResponseRequest) *domain.
 	}
 }
 
+// convertToGrammarRequest creates a chat request whose output is constrained
+// to the schema. The schema guidance stays in the prompt so a repair model
+// without grammar support can use it.
+func (s *Service) convertToGrammarRequest(req *domain.ResponseRequest) *domain.ChatRequest {
+	chatReq := s.convertToJSONModeRequest(req)
+	chatReq.AdditionalParams = nil
+	schema := req.ResponseSchema
+	chatReq.ResponseFormat = &domain.ResponseFormat{Type: domain.ResponseFormatJSONSchema, JSONSchema: &schema}
+	return chatReq
+}
+
 // convertToPromptBasedRequest creates a chat request with schema in prompt
 func (s *Service) convertToPromptBasedRequest(req *domain.ResponseRequest) *domain.ChatRequest {
-	systemPrompt := s.buildSchemaPrompt(req.ResponseSchema)
+	systemPrompt := SchemaPrompt(req.ResponseSchema)
 
 	// Check if there's already a system message
 	messages := req.Messages
@@ -286,6 +381,7 @@ func (s *Service) convertToPromptBasedRequest(req *domain.ResponseRequest) *doma
 		Messages:     messages,
 		SystemPrompt: systemPrompt,
 		Temperature:  req.Temperature,
+		TopP:         req.TopP,
 		MaxTokens:    req.MaxTokens,
 		RequestID:    req.RequestID,
 		APIKeyID:     req.APIKeyID,
@@ -294,8 +390,8 @@ func (s *Service) convertToPromptBasedRequest(req *domain.ResponseRequest) *doma
 	}
 }
 
-// buildSchemaPrompt creates a prompt with schema instructions
-func (s *Service) buildSchemaPrompt(schema domain.ResponseSchema) string {
+// SchemaPrompt creates a system prompt instructing the model to answer with JSON matching schema
+func SchemaPrompt(schema domain.ResponseSchema) string {
 	schemaJSON, _ := json.MarshalIndent(schema.Schema, "", "  ")
 
 	prompt := fmt.Sprintf(`You must respond with ONLY valid JSON that strictly conforms to this schema:
@@ -329,7 +425,7 @@ func (s *Service) validateAndParse(chatResp *domain.ChatResponse, req *domain.Re
 	// Parse and validate
 	parsedResponse, err := s.validator.ParseAndValidate(content, req.ResponseSchema.Schema)
 	if err != nil {
-		return nil, fmt.Errorf("validation failed: %w", err)
+		return nil, err
 	}
 
 	// Create structured response
@@ -0,0 +1,65 @@
+package responses
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+)
+
+// scriptedClient answers ChatComplete with canned contents in order
+type scriptedClient struct {
+	domain.LLMClient
+	replies []string
+	seen    []*domain.ChatRequest
+}
+
+func (c *scriptedClient) ChatComplete(ctx context.Context, req *domain.ChatRequest) (*domain.ChatResponse, error) {
+	c.seen = append(c.seen, req)
+	content := c.replies[len(c.seen)-1]
+	return &domain.ChatResponse{Model: req.Model, Content: content, Usage: &domain.UsageEvent{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}}, nil
+}
+
+func (c *scriptedClient) Provider() domain.Provider {
+	return domain.ProviderAnthropic
+}
+
+func TestGenerateWithRepair(t *testing.T) {
+	cfg := &config.Config{Responses: config.ResponsesConfig{ValidationRetries: 2}}
+	s := NewService(cfg, nil, nil, nil)
+	req := &domain.ResponseRequest{
+		Model:    "anthropic/claude-3-haiku",
+		Messages: []domain.Message{{Role: "user", Content: []domain.ContentBlock{{Type: "text", Text: "Capital of France?"}}}},
+		ResponseSchema: domain.ResponseSchema{Name: "answer", Schema: map[string]any{
+			"type":       "object",
+			"properties": map[string]any{"city": map[string]any{"type": "string"}},
+			"required":   []any{"city"},
+		}},
+	}
+
+	client := &scriptedClient{replies: []string{"Paris", `{"city": 42}`, `{"city": "Paris"}`}}
+	resp, err := s.generateWithPrompt(context.Background(), req, client)
+	if err != nil {
+		t.Fatalf("generateWithPrompt failed: %v", err)
+	}
+	if resp.Response["city"] != "Paris" || resp.Usage.TotalTokens != 45 {
+		t.Fatalf("expected the repaired answer with usage for every attempt, got %+v", resp)
+	}
+	if m := resp.Metadata; m.RetryCount != 2 || strings.Join(m.ValidationFailures, ",") != "invalid_json,schema_mismatch" {
+		t.Fatalf("unexpected metadata %+v", m)
+	}
+	last := client.seen[2].Messages
+	if len(last) != 5 || last[3].Role != "assistant" || !strings.Contains(last[4].Content[0].Text, "city") {
+		t.Fatalf("expected the rejected answers and validation errors to be sent back, got %+v", last)
+	}
+
+	client = &scriptedClient{replies: []string{"Paris", "Paris", "Paris"}}
+	_, err = s.generateWithPrompt(context.Background(), req, client)
+	var repairErr *RepairError
+	if !errors.As(err, &repairErr) || repairErr.Attempts != 3 || len(client.seen) != 3 {
+		t.Fatalf("expected a repair error after 3 attempts, got %v", err)
+	}
+}
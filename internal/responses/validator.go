@@ -10,6 +10,30 @@ import (
 	"github.com/xeipuuv/gojsonschema"
 )
 
+// Reasons output fails validation
+const (
+	FailureInvalidJSON    = "invalid_json"    // The output isn't JSON
+	FailureSchemaMismatch = "schema_mismatch" // The JSON doesn't match the schema
+	FailureNotObject      = "not_object"      // The JSON isn't an object
+)
+
+// ValidationError is returned when model output fails validation. Unlike
+// other errors, it can be fixed by asking the model to repair its output.
+type ValidationError struct {
+	Reason string
+	Errors []string // Individual problems found, to show the model
+}
+
+func (e *ValidationError) Error() string {
+	switch e.Reason {
+	case FailureInvalidJSON:
+		return "response is not valid JSON: " + strings.Join(e.Errors, "; ")
+	case FailureNotObject:
+		return "response is not a JSON object"
+	}
+	return "response does not match schema: " + strings.Join(e.Errors, "; ")
+}
+
 // SchemaValidator validates JSON against JSON schemas
 type SchemaValidator struct{}
 
@@ -24,9 +48,9 @@ func (v *SchemaValidator) Validate(content string, schema map[string]interface{}
 	var parsed interface{}
 	if err := json.Unmarshal([]byte(content), &parsed); err != nil {
 		// Try extracting from code blocks or mixed text
-		extracted := extractJSON(content)
+		extracted := ExtractJSON(content)
 		if err := json.Unmarshal([]byte(extracted), &parsed); err != nil {
-			return fmt.Errorf("response is not valid JSON: %w", err)
+			return &ValidationError{Reason: FailureInvalidJSON, Errors: []string{err.Error()}}
 		}
 		content = extracted
 	}
@@ -45,7 +69,7 @@ func (v *SchemaValidator) Validate(content string, schema map[string]interface{}
 		for _, err := range result.Errors() {
 			errs = append(errs, err.String())
 		}
-		return fmt.Errorf("response does not match schema: %s", strings.Join(errs, "; "))
+		return &ValidationError{Reason: FailureSchemaMismatch, Errors: errs}
 	}
 
 	return nil
@@ -61,7 +85,7 @@ func (v *SchemaValidator) ParseAndValidate(content string, schema map[string]int
 	// Extract clean JSON if needed
 	var parsed interface{}
 	if err := json.Unmarshal([]byte(content), &parsed); err != nil {
-		content = extractJSON(content)
+		content = ExtractJSON(content)
 		if err := json.Unmarshal([]byte(content), &parsed); err != nil {
 			return nil, fmt.Errorf("failed to parse validated JSON: %w", err)
 		}
@@ -70,14 +94,14 @@ func (v *SchemaValidator) ParseAndValidate(content string, schema map[string]int
 	// Convert to map
 	result, ok := parsed.(map[string]interface{})
 	if !ok {
-		return nil, fmt.Errorf("response is not a JSON object")
+		return nil, &ValidationError{Reason: FailureNotObject, Errors: []string{"the top-level value must be a JSON object"}}
 	}
 
 	return result, nil
 }
 
-// extractJSON extracts JSON from markdown code blocks or mixed text
-func extractJSON(content string) string {
+// ExtractJSON extracts JSON from markdown code blocks or mixed text
+func ExtractJSON(content string) string {
 	// Try extracting from ```json ... ``` or ``` ... ```
 	codeBlockRe := regexp.MustCompile("```(?:json)?\\s*\\n([\\s\\S]*?)\\n```")
 	if matches := codeBlockRe.FindStringSubmatch(content); len(matches) > 1 {
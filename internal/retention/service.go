@@ -0,0 +1,210 @@
+// Package retention enforces data retention policies on usage and audit tables.
+package retention
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+	"modelgate/internal/objectstore"
+)
+
+// Store is the persistence used by the service (implemented by postgres.TenantStore)
+type Store interface {
+	ListExpiredRows(ctx context.Context, table string, cutoff time.Time, limit int) ([]domain.ExpiredRow, error)
+	SoftDeleteRows(ctx context.Context, table string, ids []string) (int64, error)
+	PurgeSoftDeleted(ctx context.Context, table string, before time.Time, limit int) (int64, error)
+	ListRetentionOverrides(ctx context.Context) (map[string]*domain.RetentionOverride, error)
+	RecordRetentionRun(ctx context.Context, table string, archived, deleted int64, at time.Time) error
+}
+
+// Policies merges the config defaults with stored overrides into the effective policy for every table
+func Policies(cfg config.RetentionConfig, overrides map[string]*domain.RetentionOverride) []domain.RetentionPolicy {
+	policies := make([]domain.RetentionPolicy, 0, len(domain.RetentionTables))
+	for _, table := range domain.RetentionTables {
+		defaults := cfg.Tables[table]
+		policy := domain.RetentionPolicy{
+			Table:               table,
+			RetentionDays:       defaults.RetentionDays,
+			ArchiveBeforeDelete: defaults.Archive,
+			Enabled:             cfg.Enabled,
+		}
+		if o := overrides[table]; o != nil {
+			if o.RetentionDays != nil {
+				policy.RetentionDays = *o.RetentionDays
+			}
+			if o.ArchiveBeforeDelete != nil {
+				policy.ArchiveBeforeDelete = *o.ArchiveBeforeDelete
+			}
+			if o.Enabled != nil {
+				policy.Enabled = *o.Enabled
+			}
+			policy.LastRunAt = o.LastRunAt
+			policy.LastArchivedCount = o.LastArchivedCount
+			policy.LastDeletedCount = o.LastDeletedCount
+			policy.UpdatedAt = o.UpdatedAt
+		}
+		policies = append(policies, policy)
+	}
+	return policies
+}
+
+// Service runs the retention janitor
+type Service struct {
+	config  config.RetentionConfig
+	store   Store
+	archive objectstore.Store // nil if no archive destination is configured
+}
+
+// NewService creates a new retention service. archive may be nil, in which
+// case tables that require archiving are skipped rather than deleted.
+func NewService(cfg config.RetentionConfig, store Store, archive objectstore.Store) *Service {
+	return &Service{config: cfg, store: store, archive: archive}
+}
+
+// RunResult reports what a run did for one table
+type RunResult struct {
+	Table    string
+	Archived int64
+	Deleted  int64 // Rows soft-deleted in this run
+	Purged   int64 // Soft-deleted rows permanently removed in this run
+}
+
+// Run applies every enabled policy once
+func (s *Service) Run(ctx context.Context) ([]RunResult, error) {
+	overrides, err := s.store.ListRetentionOverrides(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list retention overrides: %w", err)
+	}
+
+	var results []RunResult
+	for _, policy := range Policies(s.config, overrides) {
+		if !policy.Enabled || policy.RetentionDays <= 0 {
+			continue
+		}
+		result, err := s.apply(ctx, policy)
+		if err != nil {
+			slog.Error("Retention run failed", "table", policy.Table, "error", err)
+		}
+		if recErr := s.store.RecordRetentionRun(ctx, policy.Table, result.Archived, result.Deleted, time.Now()); recErr != nil {
+			slog.Warn("Failed to record retention run", "table", policy.Table, "error", recErr)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// StartJanitor periodically applies retention policies until ctx is cancelled
+func (s *Service) StartJanitor(ctx context.Context) {
+	interval := s.config.Interval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				results, err := s.Run(ctx)
+				if err != nil {
+					slog.Error("Failed to apply retention policies", "error", err)
+					continue
+				}
+				for _, r := range results {
+					if r.Deleted > 0 || r.Purged > 0 {
+						slog.Info("Applied retention policy",
+							"table", r.Table, "archived", r.Archived, "deleted", r.Deleted, "purged", r.Purged)
+					}
+				}
+			}
+		}
+	}()
+}
+
+// apply soft-deletes expired rows in batches (archiving each batch first if
+// required), then purges rows whose soft-delete grace period has passed
+func (s *Service) apply(ctx context.Context, policy domain.RetentionPolicy) (RunResult, error) {
+	result := RunResult{Table: policy.Table}
+	batchSize := s.config.BatchSize
+	if batchSize <= 0 {
+		batchSize = 5000
+	}
+
+	if policy.ArchiveBeforeDelete && s.archive == nil {
+		return result, fmt.Errorf("archive required but no archive destination is configured")
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -policy.RetentionDays)
+	for {
+		rows, err := s.store.ListExpiredRows(ctx, policy.Table, cutoff, batchSize)
+		if err != nil {
+			return result, fmt.Errorf("list expired rows: %w", err)
+		}
+		if len(rows) == 0 {
+			break
+		}
+
+		if policy.ArchiveBeforeDelete {
+			if err := s.archiveRows(ctx, policy.Table, rows); err != nil {
+				return result, err
+			}
+			result.Archived += int64(len(rows))
+		}
+
+		ids := make([]string, len(rows))
+		for i, row := range rows {
+			ids[i] = row.ID
+		}
+		n, err := s.store.SoftDeleteRows(ctx, policy.Table, ids)
+		if err != nil {
+			return result, fmt.Errorf("soft-delete rows: %w", err)
+		}
+		result.Deleted += n
+
+		if len(rows) < batchSize {
+			break
+		}
+	}
+
+	purgeBefore := time.Now().Add(-s.config.SoftDeleteGrace)
+	for {
+		n, err := s.store.PurgeSoftDeleted(ctx, policy.Table, purgeBefore, batchSize)
+		if err != nil {
+			return result, fmt.Errorf("purge soft-deleted rows: %w", err)
+		}
+		result.Purged += n
+		if n < int64(batchSize) {
+			break
+		}
+	}
+	return result, nil
+}
+
+// archiveRows writes rows as gzipped JSON lines to <table>/<yyyy>/<mm>/<dd>/<table>-<nanos>.jsonl.gz
+func (s *Service) archiveRows(ctx context.Context, table string, rows []domain.ExpiredRow) error {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	for _, row := range rows {
+		gz.Write(row.Data)
+		gz.Write([]byte("\n"))
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("compress archive: %w", err)
+	}
+
+	now := time.Now().UTC()
+	key := fmt.Sprintf("%s/%s/%s-%d.jsonl.gz", table, now.Format("2006/01/02"), table, now.UnixNano())
+	if err := s.archive.Put(ctx, key, buf.Bytes(), "application/gzip"); err != nil {
+		return fmt.Errorf("archive rows: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package retention
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+	"modelgate/internal/objectstore"
+)
+
+type memRow struct {
+	id        string
+	createdAt time.Time
+	deletedAt *time.Time
+}
+
+// memStore is an in-memory Store for tests
+type memStore struct {
+	rows map[string][]*memRow
+	runs map[string][2]int64
+}
+
+func (m *memStore) ListExpiredRows(_ context.Context, table string, cutoff time.Time, limit int) ([]domain.ExpiredRow, error) {
+	var out []domain.ExpiredRow
+	for _, r := range m.rows[table] {
+		if r.deletedAt == nil && r.createdAt.Before(cutoff) && len(out) < limit {
+			out = append(out, domain.ExpiredRow{ID: r.id, Data: []byte(fmt.Sprintf(`{"id":%q}`, r.id))})
+		}
+	}
+	return out, nil
+}
+
+func (m *memStore) SoftDeleteRows(_ context.Context, table string, ids []string) (int64, error) {
+	now := time.Now()
+	var n int64
+	for _, r := range m.rows[table] {
+		for _, id := range ids {
+			if r.id == id && r.deletedAt == nil {
+				r.deletedAt = &now
+				n++
+			}
+		}
+	}
+	return n, nil
+}
+
+func (m *memStore) PurgeSoftDeleted(_ context.Context, table string, before time.Time, limit int) (int64, error) {
+	var kept []*memRow
+	var n int64
+	for _, r := range m.rows[table] {
+		if r.deletedAt != nil && r.deletedAt.Before(before) && n < int64(limit) {
+			n++
+			continue
+		}
+		kept = append(kept, r)
+	}
+	m.rows[table] = kept
+	return n, nil
+}
+
+func (m *memStore) ListRetentionOverrides(context.Context) (map[string]*domain.RetentionOverride, error) {
+	days := 30
+	return map[string]*domain.RetentionOverride{
+		domain.RetentionTableAuditLogs: {Table: domain.RetentionTableAuditLogs, RetentionDays: &days},
+	}, nil
+}
+
+func (m *memStore) RecordRetentionRun(_ context.Context, table string, archived, deleted int64, _ time.Time) error {
+	m.runs[table] = [2]int64{archived, deleted}
+	return nil
+}
+
+type memArchive struct{ objects map[string][]byte }
+
+func (a *memArchive) Put(_ context.Context, key string, data []byte, _ string) error {
+	a.objects[key] = data
+	return nil
+}
+
+func (a *memArchive) PutStream(ctx context.Context, key string, body io.Reader, _ int64, contentType string) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	return a.Put(ctx, key, data, contentType)
+}
+
+func (a *memArchive) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	data, ok := a.objects[key]
+	if !ok {
+		return nil, objectstore.ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (a *memArchive) Delete(_ context.Context, key string) error {
+	delete(a.objects, key)
+	return nil
+}
+
+func TestPoliciesMergeOverrides(t *testing.T) {
+	cfg := config.RetentionConfig{
+		Enabled: true,
+		Tables: map[string]config.RetentionTableConfig{
+			domain.RetentionTableUsageRecords: {RetentionDays: 90},
+			domain.RetentionTableAuditLogs:    {RetentionDays: 365, Archive: true},
+		},
+	}
+	disabled := false
+	days := 7
+	policies := Policies(cfg, map[string]*domain.RetentionOverride{
+		domain.RetentionTableUsageRecords: {Table: domain.RetentionTableUsageRecords, RetentionDays: &days, Enabled: &disabled},
+	})
+
+	if len(policies) != 2 {
+		t.Fatalf("expected 2 policies, got %d", len(policies))
+	}
+	usage, auditLogs := policies[0], policies[1]
+	if usage.RetentionDays != 7 || usage.Enabled {
+		t.Errorf("usage_records policy = %+v, want 7 days and disabled", usage)
+	}
+	if auditLogs.RetentionDays != 365 || !auditLogs.ArchiveBeforeDelete || !auditLogs.Enabled {
+		t.Errorf("audit_logs policy = %+v, want config defaults", auditLogs)
+	}
+}
+
+func TestRunArchivesThenSoftDeletes(t *testing.T) {
+	old := time.Now().AddDate(0, 0, -60)
+	store := &memStore{
+		rows: map[string][]*memRow{
+			domain.RetentionTableAuditLogs: {
+				{id: "a", createdAt: old},
+				{id: "b", createdAt: old},
+				{id: "c", createdAt: old},
+				{id: "d", createdAt: time.Now()},
+			},
+		},
+		runs: make(map[string][2]int64),
+	}
+	archive := &memArchive{objects: make(map[string][]byte)}
+	svc := NewService(config.RetentionConfig{
+		Enabled:         true,
+		BatchSize:       2,
+		SoftDeleteGrace: time.Hour,
+		Tables: map[string]config.RetentionTableConfig{
+			domain.RetentionTableAuditLogs: {RetentionDays: 365, Archive: true},
+		},
+	}, store, archive)
+
+	if _, err := svc.Run(context.Background()); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	// The 30-day override applies, so the three old rows are archived in two batches and soft-deleted
+	if got := store.runs[domain.RetentionTableAuditLogs]; got != [2]int64{3, 3} {
+		t.Errorf("recorded run = %v, want [3 3]", got)
+	}
+	if len(archive.objects) != 2 {
+		t.Fatalf("expected 2 archive objects, got %d", len(archive.objects))
+	}
+	var archived strings.Builder
+	for _, data := range archive.objects {
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("archive is not gzip: %v", err)
+		}
+		b, _ := io.ReadAll(gz)
+		archived.Write(b)
+	}
+	for _, id := range []string{"a", "b", "c"} {
+		if !strings.Contains(archived.String(), fmt.Sprintf(`{"id":%q}`, id)) {
+			t.Errorf("row %s missing from archive", id)
+		}
+	}
+
+	// Soft-deleted rows stay until the grace period has passed
+	if n := len(store.rows[domain.RetentionTableAuditLogs]); n != 4 {
+		t.Errorf("expected rows to be kept during grace period, got %d rows", n)
+	}
+}
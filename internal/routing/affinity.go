@@ -0,0 +1,135 @@
+package routing
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+// affinity pins conversations to the target routing picked for them, so the
+// turns of a conversation stay on one provider (and its prompt cache). Pins
+// are kept in memory for ttl after their last use; when the table is full the
+// pin closest to expiring is dropped.
+type affinity struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	maxPins int
+	pins    map[string]affinityPin
+}
+
+type affinityPin struct {
+	target  string // provider/model
+	expires time.Time
+}
+
+func newAffinity() *affinity {
+	return &affinity{pins: make(map[string]affinityPin)}
+}
+
+// configure sets how long pins last (0 disables affinity and drops the pins)
+// and how many conversations are tracked
+func (a *affinity) configure(ttl time.Duration, maxPins int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.ttl, a.maxPins = ttl, maxPins
+	if ttl <= 0 {
+		a.pins = make(map[string]affinityPin)
+	}
+}
+
+func (a *affinity) enabled() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.ttl > 0
+}
+
+// get returns the target a conversation is pinned to, extending the pin
+func (a *affinity) get(key string, now time.Time) (string, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	pin, ok := a.pins[key]
+	if !ok || a.ttl <= 0 {
+		return "", false
+	}
+	if now.After(pin.expires) {
+		delete(a.pins, key)
+		return "", false
+	}
+	pin.expires = now.Add(a.ttl)
+	a.pins[key] = pin
+	return pin.target, true
+}
+
+// set pins a conversation to a target
+func (a *affinity) set(key, target string, now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.ttl <= 0 {
+		return
+	}
+	if _, ok := a.pins[key]; !ok && a.maxPins > 0 && len(a.pins) >= a.maxPins {
+		a.evict(now)
+	}
+	a.pins[key] = affinityPin{target: target, expires: now.Add(a.ttl)}
+}
+
+// evict drops expired pins, or the one closest to expiring when none has
+func (a *affinity) evict(now time.Time) {
+	var oldestKey string
+	var oldest time.Time
+	for key, pin := range a.pins {
+		if now.After(pin.expires) {
+			delete(a.pins, key)
+			continue
+		}
+		if oldestKey == "" || pin.expires.Before(oldest) {
+			oldestKey, oldest = key, pin.expires
+		}
+	}
+	if len(a.pins) >= a.maxPins && oldestKey != "" {
+		delete(a.pins, oldestKey)
+	}
+}
+
+func (a *affinity) remove(key string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.pins, key)
+}
+
+// ConversationKey identifies the conversation a request belongs to: the
+// client's conversation ID when it sent one, otherwise a hash of the system
+// prompt and the first user message, which stay the same on every turn.
+// Keys are scoped to the API key (or role) so unrelated clients never share a
+// pin. It returns "" when the request has nothing to key on.
+func ConversationKey(req *domain.ChatRequest) string {
+	scope := req.APIKeyID
+	if scope == "" {
+		scope = req.RoleID
+	}
+	if req.ConversationID != "" {
+		return scope + ":id:" + req.ConversationID
+	}
+
+	// Everything up to and including the first user message, system prompts
+	// sent as messages included
+	var opening strings.Builder
+	opening.WriteString(req.SystemPrompt)
+	for _, msg := range req.Messages {
+		opening.WriteString("\x00" + msg.Role + "\x00")
+		for _, block := range msg.Content {
+			if block.Type == "text" {
+				opening.WriteString(block.Text)
+			}
+		}
+		if msg.Role == "user" {
+			sum := sha256.Sum256([]byte(opening.String()))
+			return scope + ":hash:" + hex.EncodeToString(sum[:16])
+		}
+	}
+	return ""
+}
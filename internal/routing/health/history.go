@@ -0,0 +1,209 @@
+package health
+
+import (
+	"math/rand/v2"
+	"sort"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+// maxBucketLatencies bounds the latencies kept per provider/model between
+// history samples; past it a uniform sample of them is kept
+const maxBucketLatencies = 10000
+
+// Circuit breaker states, as resilience.CircuitBreaker names them
+const (
+	breakerClosed   = "closed"
+	breakerHalfOpen = "half_open"
+	breakerOpen     = "open"
+)
+
+// historyBucket accumulates a provider/model's outcomes between history samples
+type historyBucket struct {
+	provider     string
+	model        string
+	requests     int64
+	failures     int64
+	clientErrors int64
+	latencies    []int // Of successful requests
+	succeeded    int
+}
+
+func (b *historyBucket) add(o outcome) {
+	switch {
+	case !o.failed:
+		b.requests++
+		b.succeeded++
+		if len(b.latencies) < maxBucketLatencies {
+			b.latencies = append(b.latencies, o.latencyMs)
+		} else if i := rand.IntN(b.succeeded); i < maxBucketLatencies {
+			b.latencies[i] = o.latencyMs
+		}
+	case providerFault(o.errorType):
+		b.requests++
+		b.failures++
+	default:
+		b.clientErrors++
+	}
+}
+
+// breakerHistory follows a provider's circuit breaker between history samples
+type breakerHistory struct {
+	state       string
+	since       time.Time // When the breaker entered state, or the last sample if later
+	worst       string    // Worst state since the last sample
+	openSeconds float64   // Time open since the last sample, up to since
+}
+
+// providerFault reports whether a failure counts against the provider's
+// service level. Requests the provider refused because of the caller, or of
+// the gateway's configuration, don't.
+func providerFault(errorType string) bool {
+	switch errorType {
+	case domain.ErrorCodeContextLength, domain.ErrorCodeContentFilter, domain.ErrorCodeInvalidAPIKey,
+		domain.ErrorCodeModelNotFound, domain.ErrorCodeInvalidRequest, domain.ErrorCodeCancelled,
+		domain.ErrorCodeInsufficientQuota, domain.ErrorCodeToolsNotSupported, domain.ErrorCodeVisionNotSupported,
+		domain.ErrorCodeReasoningNotSupported, domain.ErrorCodeGrammarNotSupported, domain.ErrorCodeCostLimitExceeded,
+		domain.ErrorCodeConversationBudgetExceeded,
+		domain.ErrorCodeProviderDisabled, domain.ErrorCodeModelDisabled:
+		return false
+	}
+	return true
+}
+
+func breakerSeverity(state string) int {
+	switch state {
+	case breakerOpen:
+		return 2
+	case breakerHalfOpen:
+		return 1
+	}
+	return 0
+}
+
+// EnableHistory starts keeping outcomes and breaker states for TakeHistory
+func (t *Tracker) EnableHistory() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.history = true
+}
+
+// addToHistory adds an outcome to its provider/model's history bucket.
+// Callers hold t.mu.
+func (t *Tracker) addToHistory(provider, model string, o outcome) {
+	if !t.history {
+		return
+	}
+	key := windowKey(provider, model)
+	b, ok := t.buckets[key]
+	if !ok {
+		b = &historyBucket{provider: provider, model: key[len(provider)+1:]}
+		t.buckets[key] = b
+	}
+	b.add(o)
+}
+
+// RecordBreakerState records a change of a provider's circuit breaker state
+// for the health history
+func (t *Tracker) RecordBreakerState(provider, state string) {
+	now := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.history {
+		return
+	}
+	b, ok := t.breakers[provider]
+	if !ok {
+		b = &breakerHistory{state: breakerClosed, worst: breakerClosed, since: now}
+		t.breakers[provider] = b
+	}
+	if b.state == breakerOpen {
+		b.openSeconds += now.Sub(b.since).Seconds()
+	}
+	b.state, b.since = state, now
+	if breakerSeverity(state) > breakerSeverity(b.worst) {
+		b.worst = state
+	}
+}
+
+// TakeHistory returns a sample of each provider/model, and of each provider
+// as a whole, covering the outcomes since the last call, and starts over.
+// The samples are stamped with the bucket they are for.
+func (t *Tracker) TakeHistory(bucketStart time.Time, bucket time.Duration) []domain.ProviderHealthSample {
+	now := time.Now()
+	t.mu.Lock()
+	buckets := t.buckets
+	t.buckets = make(map[string]*historyBucket)
+	type breakerSample struct {
+		worst       string
+		openSeconds float64
+	}
+	breakers := make(map[string]breakerSample, len(t.breakers))
+	for provider, b := range t.breakers {
+		open := b.openSeconds
+		if b.state == breakerOpen {
+			open += now.Sub(b.since).Seconds()
+		}
+		breakers[provider] = breakerSample{worst: b.worst, openSeconds: min(open, bucket.Seconds())}
+		if b.state == breakerClosed {
+			delete(t.breakers, provider)
+			continue
+		}
+		b.since, b.worst, b.openSeconds = now, b.state, 0
+	}
+	t.mu.Unlock()
+
+	sample := func(provider, model string, b *historyBucket) domain.ProviderHealthSample {
+		s := domain.ProviderHealthSample{
+			BucketStart:   bucketStart,
+			BucketSeconds: int(bucket.Seconds()),
+			Provider:      provider,
+			Model:         model,
+			Requests:      b.requests,
+			Failures:      b.failures,
+			ClientErrors:  b.clientErrors,
+			BreakerState:  breakerClosed,
+		}
+		if len(b.latencies) > 0 {
+			sort.Ints(b.latencies)
+			s.P50LatencyMs = float64(percentile(b.latencies, 50))
+			s.P95LatencyMs = float64(percentile(b.latencies, 95))
+			s.P99LatencyMs = float64(percentile(b.latencies, 99))
+		}
+		if br, ok := breakers[provider]; ok {
+			s.BreakerState, s.BreakerOpenSeconds = br.worst, br.openSeconds
+		}
+		return s
+	}
+
+	providers := make(map[string]*historyBucket)
+	var samples []domain.ProviderHealthSample
+	for _, b := range buckets {
+		p, ok := providers[b.provider]
+		if !ok {
+			p = &historyBucket{provider: b.provider}
+			providers[b.provider] = p
+		}
+		p.requests += b.requests
+		p.failures += b.failures
+		p.clientErrors += b.clientErrors
+		p.latencies = append(p.latencies, b.latencies...)
+		samples = append(samples, sample(b.provider, b.model, b))
+	}
+	for provider, br := range breakers {
+		if _, ok := providers[provider]; !ok && (br.worst != breakerClosed || br.openSeconds > 0) {
+			providers[provider] = &historyBucket{provider: provider}
+		}
+	}
+	for provider, p := range providers {
+		samples = append(samples, sample(provider, "", p))
+	}
+	sort.Slice(samples, func(i, j int) bool {
+		if samples[i].Provider != samples[j].Provider {
+			return samples[i].Provider < samples[j].Provider
+		}
+		return samples[i].Model < samples[j].Model
+	})
+	return samples
+}
@@ -0,0 +1,136 @@
+package health
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Rolling latency window tuning
+const (
+	latencyWindowSize = 200              // Outcomes kept per provider/model
+	latencyWindowAge  = 10 * time.Minute // Outcomes older than this are ignored
+)
+
+// LatencyStats summarizes a provider/model's recent requests
+type LatencyStats struct {
+	Samples   int     // Outcomes in the window
+	P50Ms     float64 // Median latency of successful requests
+	P95Ms     float64
+	ErrorRate float64 // Fraction of failed requests, 0.0-1.0
+
+	// Streamed requests only; zero without any in the window
+	P50TimeToFirstTokenMs float64
+	P95TimeToFirstTokenMs float64
+	AvgTokensPerSecond    float64
+}
+
+type outcome struct {
+	at              time.Time
+	latencyMs       int
+	failed          bool
+	ttftMs          int // 0 for requests that weren't streamed
+	tokensPerSecond float64
+	errorType       string
+}
+
+// latencyWindow is a ring buffer of a target's most recent outcomes
+type latencyWindow struct {
+	outcomes [latencyWindowSize]outcome
+	next     int
+	count    int
+}
+
+func (w *latencyWindow) add(o outcome) {
+	w.outcomes[w.next] = o
+	w.next = (w.next + 1) % latencyWindowSize
+	if w.count < latencyWindowSize {
+		w.count++
+	}
+}
+
+func (w *latencyWindow) stats(now time.Time) LatencyStats {
+	var stats LatencyStats
+	latencies := make([]int, 0, w.count)
+	var ttfts []int
+	var tokensPerSecond float64
+	var throughputSamples int
+	failures := 0
+	for i := 0; i < w.count; i++ {
+		o := w.outcomes[i]
+		if now.Sub(o.at) > latencyWindowAge {
+			continue
+		}
+		stats.Samples++
+		if o.failed {
+			failures++
+		} else {
+			latencies = append(latencies, o.latencyMs)
+		}
+		if o.ttftMs > 0 {
+			ttfts = append(ttfts, o.ttftMs)
+		}
+		if o.tokensPerSecond > 0 {
+			tokensPerSecond += o.tokensPerSecond
+			throughputSamples++
+		}
+	}
+	if stats.Samples == 0 {
+		return stats
+	}
+	stats.ErrorRate = float64(failures) / float64(stats.Samples)
+	if len(latencies) > 0 {
+		sort.Ints(latencies)
+		stats.P50Ms = float64(percentile(latencies, 50))
+		stats.P95Ms = float64(percentile(latencies, 95))
+	}
+	if len(ttfts) > 0 {
+		sort.Ints(ttfts)
+		stats.P50TimeToFirstTokenMs = float64(percentile(ttfts, 50))
+		stats.P95TimeToFirstTokenMs = float64(percentile(ttfts, 95))
+	}
+	if throughputSamples > 0 {
+		stats.AvgTokensPerSecond = tokensPerSecond / float64(throughputSamples)
+	}
+	return stats
+}
+
+// percentile returns the nearest-rank percentile of sorted values
+func percentile(sorted []int, p int) int {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// windowKey identifies a target; the model may or may not carry a "provider/" prefix
+func windowKey(provider, model string) string {
+	return provider + ":" + strings.TrimPrefix(model, provider+"/")
+}
+
+func (t *Tracker) recordOutcome(provider, model string, o outcome) {
+	key := windowKey(provider, model)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	w, ok := t.windows[key]
+	if !ok {
+		w = &latencyWindow{}
+		t.windows[key] = w
+	}
+	o.at = time.Now()
+	w.add(o)
+	t.addToHistory(provider, model, o)
+}
+
+// Latency returns rolling latency percentiles and the error rate for a
+// provider/model over its most recent requests
+func (t *Tracker) Latency(provider, model string) LatencyStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	w, ok := t.windows[windowKey(provider, model)]
+	if !ok {
+		return LatencyStats{}
+	}
+	return w.stats(time.Now())
+}
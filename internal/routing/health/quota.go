@@ -0,0 +1,67 @@
+package health
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+// KeyQuota is the latest upstream rate limit state for a provider API key
+type KeyQuota struct {
+	Provider string `json:"provider"`
+	KeyID    string `json:"key_id"` // Empty for credentials outside provider_api_keys
+	domain.ProviderQuota
+}
+
+func quotaKey(provider, keyID string) string {
+	return provider + ":" + keyID
+}
+
+// RecordQuota stores the rate limit headers a provider returned for a key,
+// merged with what earlier responses reported
+func (t *Tracker) RecordQuota(provider, keyID string, q domain.ProviderQuota) {
+	key := quotaKey(provider, keyID)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if prev, ok := t.quotas[key]; ok && q.ObservedAt.Before(prev.ExpiresAt()) {
+		q = prev.Merge(q)
+	}
+	t.quotas[key] = q
+}
+
+// KeyQuota returns the current rate limit state for a key, or false when none
+// has been reported or the last report has expired
+func (t *Tracker) KeyQuota(provider, keyID string) (domain.ProviderQuota, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	q, ok := t.quotas[quotaKey(provider, keyID)]
+	if !ok || !time.Now().Before(q.ExpiresAt()) {
+		return domain.ProviderQuota{}, false
+	}
+	return q, true
+}
+
+// Quotas returns the current rate limit state of every key, dropping expired reports
+func (t *Tracker) Quotas() []KeyQuota {
+	now := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make([]KeyQuota, 0, len(t.quotas))
+	for key, q := range t.quotas {
+		if !now.Before(q.ExpiresAt()) {
+			delete(t.quotas, key)
+			continue
+		}
+		provider, keyID, _ := strings.Cut(key, ":")
+		out = append(out, KeyQuota{Provider: provider, KeyID: keyID, ProviderQuota: q})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Provider != out[j].Provider {
+			return out[i].Provider < out[j].Provider
+		}
+		return out[i].KeyID < out[j].KeyID
+	})
+	return out
+}
@@ -5,6 +5,8 @@ import (
 	"database/sql"
 	"sync"
 	"time"
+
+	"modelgate/internal/domain"
 )
 
 // ProviderHealth represents health metrics for a provider
@@ -25,25 +27,50 @@ type ProviderHealth struct {
 type Tracker struct {
 	db    *sql.DB
 	cache sync.Map // tenant:provider:model -> *ProviderHealth
+
+	mu       sync.Mutex
+	windows  map[string]*latencyWindow       // provider:model -> recent outcomes
+	quotas   map[string]domain.ProviderQuota // provider:key ID -> upstream rate limits
+	history  bool                            // Outcomes are kept for TakeHistory
+	buckets  map[string]*historyBucket       // provider:model -> outcomes since the last history sample
+	breakers map[string]*breakerHistory      // provider -> circuit breaker state since the last history sample
 }
 
 // NewTracker creates a new health tracker
 func NewTracker(db *sql.DB) *Tracker {
-	return &Tracker{db: db}
+	return &Tracker{
+		db:       db,
+		windows:  make(map[string]*latencyWindow),
+		quotas:   make(map[string]domain.ProviderQuota),
+		buckets:  make(map[string]*historyBucket),
+		breakers: make(map[string]*breakerHistory),
+	}
 }
 
 // RecordSuccess updates health metrics after successful request
 func (t *Tracker) RecordSuccess(ctx context.Context, tenantID, provider, model string, latencyMs int) {
+	t.recordOutcome(provider, model, outcome{latencyMs: latencyMs})
+	go t.updateHealth(context.Background(), tenantID, provider, model, true, latencyMs, "")
+}
+
+// RecordStreamSuccess updates health metrics after a successful streamed
+// request, including its time to first token and throughput
+func (t *Tracker) RecordStreamSuccess(ctx context.Context, tenantID, provider, model string, latencyMs, ttftMs int, tokensPerSecond float64) {
+	t.recordOutcome(provider, model, outcome{latencyMs: latencyMs, ttftMs: ttftMs, tokensPerSecond: tokensPerSecond})
 	go t.updateHealth(context.Background(), tenantID, provider, model, true, latencyMs, "")
 }
 
 // RecordFailure updates health metrics after failed request
 func (t *Tracker) RecordFailure(ctx context.Context, tenantID, provider, model, errorType string) {
+	t.recordOutcome(provider, model, outcome{failed: true, errorType: errorType})
 	go t.updateHealth(context.Background(), tenantID, provider, model, false, 0, errorType)
 }
 
 // updateHealth updates health metrics in database
 func (t *Tracker) updateHealth(ctx context.Context, tenantID, provider, model string, success bool, latencyMs int, errorType string) {
+	if t.db == nil {
+		return
+	}
 	query := `SELECT update_provider_health($1, $2, $3, $4, $5, $6)`
 
 	_, err := t.db.ExecContext(ctx, query, tenantID, provider, model, success, latencyMs, errorType)
@@ -4,8 +4,11 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"modelgate/internal/domain"
 	"modelgate/internal/routing/health"
@@ -17,20 +20,45 @@ type ProviderConfigSource interface {
 	GetProviderModels(ctx context.Context, tenantID, provider string) ([]string, error)
 }
 
+// Readiness reports the gateway's degraded mode (implemented by readiness.Checker)
+type Readiness interface {
+	Degraded() bool
+	ProviderReady(provider string) bool
+}
+
+// KillSwitch reports providers and models an admin stopped traffic to
+// (implemented by killswitch.Service). An empty model checks the provider only.
+type KillSwitch interface {
+	Disabled(provider, model string) bool
+}
+
 // DefaultProviderModels contains fallback model lists per provider
 var DefaultProviderModels = map[string][]string{
-	"openai":    {"gpt-4o", "gpt-4o-mini"},
-	"anthropic": {"claude-sonnet-4-20250514", "claude-3-5-haiku-20241022"},
-	"gemini":    {"gemini-2.0-flash-exp"},
-	"ollama":    {"llama3.2"},
-	"bedrock":   {"anthropic.claude-3-5-sonnet-20241022-v2:0"},
-	"azure":     {"gpt-4"},
-	"groq":      {"llama-3.1-70b-versatile"},
-	"mistral":   {"mistral-large-latest"},
-	"together":  {"meta-llama/Meta-Llama-3.1-70B-Instruct-Turbo"},
-	"cohere":    {"command-r-plus"},
+	"openai":      {"gpt-4o", "gpt-4o-mini"},
+	"anthropic":   {"claude-sonnet-4-20250514", "claude-3-5-haiku-20241022"},
+	"gemini":      {"gemini-2.0-flash-exp"},
+	"ollama":      {"llama3.2"},
+	"bedrock":     {"anthropic.claude-3-5-sonnet-20241022-v2:0"},
+	"azure":       {"gpt-4"},
+	"groq":        {"llama-3.1-70b-versatile"},
+	"mistral":     {"mistral-large-latest"},
+	"together":    {"meta-llama/Meta-Llama-3.1-70B-Instruct-Turbo"},
+	"cohere":      {"command-r-plus"},
+	"openrouter":  {"openai/gpt-4o-mini"},
+	"xai":         {"grok-4-fast-non-reasoning"},
+	"deepseek":    {"deepseek-chat"},
+	"huggingface": {"meta-llama/Llama-3.1-8B-Instruct"},
+	"fireworks":   {"llama-v3p3-70b-instruct"},
 }
 
+// Latency routing defaults
+const (
+	defaultHysteresisPercent = 15
+	defaultMaxErrorRate      = 0.5
+	defaultLatencyMs         = 500 // Assumed for targets without latency data
+	minHealthSamples         = 5   // Requests needed before a target can be judged unhealthy
+)
+
 // DefaultProviders is the fallback list of available providers
 var DefaultProviders = []string{"openai", "anthropic", "gemini", "ollama"}
 
@@ -40,7 +68,11 @@ type Router struct {
 	configSource  ProviderConfigSource
 	providerCache map[string][]string // provider -> available models
 	mu            sync.RWMutex
-	roundRobinIdx map[string]int // For round-robin strategy
+	roundRobinIdx map[string]int    // For round-robin strategy
+	latencyChoice map[string]string // role -> provider/model last picked by the latency strategy
+	readiness     Readiness
+	killSwitch    KillSwitch
+	affinity      *affinity // conversation -> provider/model, for sticky routing
 }
 
 // NewRouter creates a new router with default configuration
@@ -49,6 +81,8 @@ func NewRouter(healthTracker *health.Tracker) *Router {
 		healthTracker: healthTracker,
 		providerCache: make(map[string][]string),
 		roundRobinIdx: make(map[string]int),
+		latencyChoice: make(map[string]string),
+		affinity:      newAffinity(),
 	}
 }
 
@@ -59,25 +93,122 @@ func NewRouterWithConfig(healthTracker *health.Tracker, configSource ProviderCon
 		configSource:  configSource,
 		providerCache: make(map[string][]string),
 		roundRobinIdx: make(map[string]int),
+		latencyChoice: make(map[string]string),
+		affinity:      newAffinity(),
 	}
 }
 
-// Route selects the best provider and model based on policy
-func (r *Router) Route(ctx context.Context, req *domain.ChatRequest, policy domain.RoutingPolicy) (provider, model string, err error) {
+// Route selects the best provider and model based on policy and records the
+// decision on req.RoutingDecision. With sticky routing, later turns of a
+// conversation go to the target picked for its first turn while that target
+// is still allowed, ready and healthy.
+func (r *Router) Route(ctx context.Context, req *domain.ChatRequest, policy domain.RoutingPolicy, restrictions domain.ModelRestrictions) (provider, model string, err error) {
+	req.RoutingDecision = nil
+	var key string
+	if r.affinity.enabled() {
+		key = ConversationKey(req)
+	}
+	if key != "" {
+		if target, ok := r.affinity.get(key, time.Now()); ok {
+			provider, model = r.parseModelID(target)
+			if r.usable(provider, model, restrictions) {
+				req.RoutingDecision = &domain.RoutingDecision{
+					Strategy:    policy.Strategy,
+					Model:       target,
+					Reason:      "conversation affinity",
+					Sticky:      true,
+					AffinityKey: key,
+				}
+				return provider, model, nil
+			}
+			r.affinity.remove(key)
+		}
+	}
+
 	switch policy.Strategy {
 	case domain.RoutingStrategyCost:
-		return r.routeByCost(ctx, req, policy.CostConfig)
+		provider, model, err = r.routeByCost(ctx, req, policy.CostConfig)
 	case domain.RoutingStrategyLatency:
-		return r.routeByLatency(ctx, req, policy.LatencyConfig)
+		provider, model, err = r.routeByLatency(ctx, req, policy.LatencyConfig, restrictions)
 	case domain.RoutingStrategyWeighted:
-		return r.routeByWeighted(ctx, req, policy.WeightedConfig)
+		provider, model, err = r.routeByWeighted(ctx, req, policy.WeightedConfig)
 	case domain.RoutingStrategyRoundRobin:
-		return r.routeRoundRobin(ctx, req)
+		provider, model, err = r.routeRoundRobin(ctx, req)
 	case domain.RoutingStrategyCapability:
-		return r.routeByCapability(ctx, req, policy.CapabilityConfig)
+		provider, model, err = r.routeByCapability(ctx, req, policy.CapabilityConfig)
 	default:
 		return "", "", fmt.Errorf("unknown routing strategy: %s", policy.Strategy)
 	}
+	if err == nil && req.RoutingDecision == nil {
+		req.RoutingDecision = &domain.RoutingDecision{Strategy: policy.Strategy, Model: provider + "/" + model}
+	}
+	if err == nil && key != "" && provider != "" {
+		r.affinity.set(key, provider+"/"+model, time.Now())
+		req.RoutingDecision.AffinityKey = key
+	}
+	return provider, model, err
+}
+
+// usable reports whether a pinned target may still serve a conversation
+func (r *Router) usable(provider, model string, restrictions domain.ModelRestrictions) bool {
+	if !permitted(restrictions, provider, model) || r.avoided(provider, model) {
+		return false
+	}
+	stats := r.healthTracker.Latency(provider, model)
+	return stats.Samples < minHealthSamples || stats.ErrorRate < defaultMaxErrorRate
+}
+
+// SetAffinity configures sticky routing: conversations stay pinned to their
+// target for ttl after their last turn (0 disables it), for up to
+// maxConversations conversations at a time
+func (r *Router) SetAffinity(ttl time.Duration, maxConversations int) {
+	r.affinity.configure(ttl, maxConversations)
+}
+
+// ReleaseAffinity unpins the request's conversation, so its next turn is
+// routed afresh. Called when the pinned target failed the request.
+func (r *Router) ReleaseAffinity(req *domain.ChatRequest) {
+	if d := req.RoutingDecision; d != nil && d.AffinityKey != "" {
+		r.affinity.remove(d.AffinityKey)
+	}
+}
+
+// Alternatives lists the models a routing policy may send requests to, most
+// capable tier first, dropping targets the role may not use or whose provider
+// isn't ready. Models without a provider prefix are returned as listed.
+func (r *Router) Alternatives(policy domain.RoutingPolicy, restrictions domain.ModelRestrictions) []string {
+	var models []string
+	if c := policy.CapabilityConfig; c != nil {
+		tasks := make([]string, 0, len(c.TaskModels))
+		for task := range c.TaskModels {
+			tasks = append(tasks, task)
+		}
+		sort.Strings(tasks)
+		for _, task := range tasks {
+			models = append(models, c.TaskModels[task]...)
+		}
+	}
+	if c := policy.CostConfig; c != nil {
+		models = append(models, c.ComplexModels...)
+		models = append(models, c.MediumModels...)
+		models = append(models, c.SimpleModels...)
+	}
+	if c := policy.LatencyConfig; c != nil {
+		models = append(models, c.PreferredModels...)
+	}
+	models = append(models, restrictions.AllowedModels...)
+
+	var alternatives []string
+	for _, m := range models {
+		if slices.Contains(alternatives, m) {
+			continue
+		}
+		if provider, model := r.parseModelID(m); provider != "" && !r.usable(provider, model, restrictions) {
+			continue
+		}
+		alternatives = append(alternatives, m)
+	}
+	return alternatives
 }
 
 // routeByCost analyzes prompt complexity and routes to appropriate tier
@@ -153,42 +284,111 @@ func (r *Router) analyzeComplexity(messages []domain.Message, tools []domain.Too
 	return complexity
 }
 
-// routeByLatency selects provider with best latency
-func (r *Router) routeByLatency(ctx context.Context, req *domain.ChatRequest, config *domain.LatencyRoutingConfig) (string, string, error) {
+// routeByLatency picks the fastest healthy target from the preferred models
+// using the health tracker's rolling percentiles. To avoid flapping, the
+// role's current target is kept until another one is faster by the
+// configured hysteresis margin.
+func (r *Router) routeByLatency(ctx context.Context, req *domain.ChatRequest, config *domain.LatencyRoutingConfig, restrictions domain.ModelRestrictions) (string, string, error) {
 	if config == nil || len(config.PreferredModels) == 0 {
 		return "", "", fmt.Errorf("latency routing config is required")
 	}
 
-	bestProvider := ""
-	bestModel := ""
-	bestLatency := float64(999999)
+	percentile := config.Percentile
+	if percentile != "p95" {
+		percentile = "p50"
+	}
+	hysteresis := config.HysteresisPercent
+	if hysteresis <= 0 {
+		hysteresis = defaultHysteresisPercent
+	}
+	maxErrorRate := config.MaxErrorRate
+	if maxErrorRate <= 0 {
+		maxErrorRate = defaultMaxErrorRate
+	}
 
+	type target struct {
+		id, provider, model string
+		latency             float64
+		measured            bool
+	}
+	var targets []target
 	for _, modelID := range config.PreferredModels {
 		provider, model := r.parseModelID(modelID)
+		if !permitted(restrictions, provider, model) || r.avoided(provider, model) {
+			continue
+		}
 
-		health, err := r.healthTracker.GetHealth(ctx, "", provider, model)
-		if err != nil {
+		stats := r.healthTracker.Latency(provider, model)
+		if stats.Samples >= minHealthSamples && stats.ErrorRate >= maxErrorRate {
+			continue // Unhealthy until its failures age out of the window
+		}
+		latency := stats.P50Ms
+		if percentile == "p95" {
+			latency = stats.P95Ms
+		}
+		measured := latency > 0
+		if !measured {
+			latency = defaultLatencyMs // No successful requests yet
+		}
+		if config.MaxLatencyMs > 0 && latency >= float64(config.MaxLatencyMs) {
 			continue
 		}
+		targets = append(targets, target{id: provider + "/" + model, provider: provider, model: model, latency: latency, measured: measured})
+	}
+
+	if len(targets) == 0 {
+		return "", "", fmt.Errorf("no healthy model meets latency requirement of %dms", config.MaxLatencyMs)
+	}
 
-		// Handle new providers with no latency data (assume reasonable default)
-		avgLatency := health.AvgLatencyMs
-		if avgLatency == 0 && health.TotalRequests == 0 {
-			avgLatency = 500 // Default 500ms for new providers
+	best := targets[0]
+	for _, t := range targets[1:] {
+		if t.latency < best.latency {
+			best = t
 		}
+	}
+	chosen := best
+	reason := fmt.Sprintf("lowest %s latency of %d candidate(s)", percentile, len(targets))
+	if !best.measured {
+		reason += fmt.Sprintf("; no latency data yet, assumed %.0fms", best.latency)
+	}
 
-		if avgLatency < bestLatency && avgLatency < float64(config.MaxLatencyMs) {
-			bestProvider = provider
-			bestModel = model
-			bestLatency = avgLatency
+	r.mu.Lock()
+	if current, ok := r.latencyChoice[req.RoleID]; ok && current != best.id {
+		for _, t := range targets {
+			if t.id != current {
+				continue
+			}
+			if best.latency > t.latency*(1-float64(hysteresis)/100) {
+				chosen = t
+				reason = fmt.Sprintf("kept current target; %s is less than %d%% faster", best.id, hysteresis)
+			}
+			break
 		}
 	}
+	r.latencyChoice[req.RoleID] = chosen.id
+	r.mu.Unlock()
 
-	if bestProvider == "" {
-		return "", "", fmt.Errorf("no provider meets latency requirement of %dms", config.MaxLatencyMs)
+	req.RoutingDecision = &domain.RoutingDecision{
+		Strategy:   domain.RoutingStrategyLatency,
+		Model:      chosen.id,
+		Reason:     reason,
+		Percentile: percentile,
+		LatencyMs:  chosen.latency,
+		Candidates: len(targets),
 	}
+	return chosen.provider, chosen.model, nil
+}
 
-	return bestProvider, bestModel, nil
+// permitted reports whether the role's model restrictions allow a target.
+// Allowed models may be listed with or without the provider prefix.
+func permitted(restrictions domain.ModelRestrictions, provider, model string) bool {
+	if len(restrictions.AllowedProviders) > 0 && !slices.Contains(restrictions.AllowedProviders, domain.Provider(provider)) {
+		return false
+	}
+	if len(restrictions.AllowedModels) > 0 {
+		return slices.Contains(restrictions.AllowedModels, provider+"/"+model) || slices.Contains(restrictions.AllowedModels, model)
+	}
+	return true
 }
 
 // routeByWeighted distributes requests by configured weights
@@ -223,8 +423,13 @@ func (r *Router) routeByWeighted(ctx context.Context, req *domain.ChatRequest, c
 		weight := config.Weights[provider]
 		cumulative += weight
 		if random < cumulative {
+			if r.unready(provider) {
+				continue
+			}
 			// Select a model from this provider
-			models := r.getProviderModels(ctx, "", provider)
+			models := slices.DeleteFunc(slices.Clone(r.getProviderModels(ctx, "", provider)), func(m string) bool {
+				return r.avoided(provider, m)
+			})
 			if len(models) == 0 {
 				// This provider has no models, skip and try next
 				continue
@@ -238,7 +443,7 @@ func (r *Router) routeByWeighted(ctx context.Context, req *domain.ChatRequest, c
 
 // routeRoundRobin cycles through available providers
 func (r *Router) routeRoundRobin(ctx context.Context, req *domain.ChatRequest) (string, string, error) {
-	providers := r.getAvailableProviders(ctx, "")
+	providers := slices.DeleteFunc(slices.Clone(r.getAvailableProviders(ctx, "")), r.unready)
 	if len(providers) == 0 {
 		return "", "", fmt.Errorf("no providers available")
 	}
@@ -249,7 +454,9 @@ func (r *Router) routeRoundRobin(ctx context.Context, req *domain.ChatRequest) (
 	r.roundRobinIdx["default"] = idx + 1
 	r.mu.Unlock()
 
-	models := r.getProviderModels(ctx, "", provider)
+	models := slices.DeleteFunc(slices.Clone(r.getProviderModels(ctx, "", provider)), func(m string) bool {
+		return r.avoided(provider, m)
+	})
 	if len(models) == 0 {
 		return "", "", fmt.Errorf("no models available for provider %s", provider)
 	}
@@ -320,8 +527,16 @@ func (r *Router) selectBestCandidate(ctx context.Context, tenantID string, candi
 	bestModel := ""
 	bestScore := -1.0 // Allow 0 scores to be selected
 
+	fallback := candidates[0] // First ready candidate
+	fallbackSet := false
 	for _, modelID := range candidates {
 		provider, model := r.parseModelID(modelID)
+		if r.avoided(provider, model) {
+			continue
+		}
+		if !fallbackSet {
+			fallback, fallbackSet = modelID, true
+		}
 
 		health, err := r.healthTracker.GetHealth(ctx, tenantID, provider, model)
 		if err != nil {
@@ -336,8 +551,8 @@ func (r *Router) selectBestCandidate(ctx context.Context, tenantID string, candi
 	}
 
 	if bestProvider == "" {
-		// Fallback to first candidate (no health data available)
-		bestProvider, bestModel = r.parseModelID(candidates[0])
+		// Fallback to first ready candidate (no health data available)
+		bestProvider, bestModel = r.parseModelID(fallback)
 	}
 
 	return bestProvider, bestModel, nil
@@ -400,6 +615,32 @@ func (r *Router) SetProviderModels(provider string, models []string) {
 	r.providerCache[provider] = models
 }
 
+// SetReadiness makes the router avoid providers without a usable key while
+// the gateway is degraded
+func (r *Router) SetReadiness(readiness Readiness) {
+	r.readiness = readiness
+}
+
+// SetKillSwitch makes the router avoid providers and models an admin switched off
+func (r *Router) SetKillSwitch(killSwitch KillSwitch) {
+	r.killSwitch = killSwitch
+}
+
+// unready reports whether provider should be avoided because it is switched
+// off, or the gateway is degraded and found no usable key for it
+func (r *Router) unready(provider string) bool {
+	return r.avoided(provider, "")
+}
+
+// avoided reports whether a target should get no traffic: its provider or
+// model is switched off, or the provider is unready
+func (r *Router) avoided(provider, model string) bool {
+	if r.killSwitch != nil && r.killSwitch.Disabled(provider, model) {
+		return true
+	}
+	return r.readiness != nil && r.readiness.Degraded() && !r.readiness.ProviderReady(provider)
+}
+
 // SetConfigSource sets the provider configuration source
 func (r *Router) SetConfigSource(source ProviderConfigSource) {
 	r.configSource = source
@@ -0,0 +1,137 @@
+package routing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"modelgate/internal/domain"
+	"modelgate/internal/routing/health"
+)
+
+func record(tracker *health.Tracker, provider, model string, latencyMs, n int) {
+	for i := 0; i < n; i++ {
+		tracker.RecordSuccess(context.Background(), "", provider, model, latencyMs)
+	}
+}
+
+func TestLatencyRoutingHysteresis(t *testing.T) {
+	tracker := health.NewTracker(nil)
+	router := NewRouter(tracker)
+	policy := domain.RoutingPolicy{
+		Strategy: domain.RoutingStrategyLatency,
+		LatencyConfig: &domain.LatencyRoutingConfig{
+			PreferredModels:   []string{"openai/gpt-4o", "anthropic/claude-sonnet-4", "groq/llama-3.1-70b"},
+			HysteresisPercent: 20,
+		},
+	}
+	restrictions := domain.ModelRestrictions{AllowedProviders: []domain.Provider{"openai", "anthropic"}}
+	route := func() string {
+		req := &domain.ChatRequest{RoleID: "role-1"}
+		provider, model, err := router.Route(context.Background(), req, policy, restrictions)
+		if err != nil {
+			t.Fatalf("Route: %v", err)
+		}
+		if req.RoutingDecision == nil || req.RoutingDecision.Model != provider+"/"+model {
+			t.Fatalf("expected a routing decision for %s/%s, got %+v", provider, model, req.RoutingDecision)
+		}
+		return provider + "/" + model
+	}
+
+	// groq is fastest but not allowed for the role
+	record(tracker, "openai", "gpt-4o", 400, 10)
+	record(tracker, "anthropic", "claude-sonnet-4", 300, 10)
+	record(tracker, "groq", "llama-3.1-70b", 50, 10)
+	if got := route(); got != "anthropic/claude-sonnet-4" {
+		t.Fatalf("expected the fastest allowed target, got %s", got)
+	}
+
+	// openai becomes slightly faster: not enough to switch
+	record(tracker, "openai", "gpt-4o", 280, 20)
+	if got := route(); got != "anthropic/claude-sonnet-4" {
+		t.Fatalf("expected hysteresis to keep the current target, got %s", got)
+	}
+
+	// The current target starts failing and is skipped
+	for i := 0; i < 20; i++ {
+		tracker.RecordFailure(context.Background(), "", "anthropic", "claude-sonnet-4", "provider_error")
+	}
+	if got := route(); got != "openai/gpt-4o" {
+		t.Fatalf("expected to move off the unhealthy target, got %s", got)
+	}
+}
+
+func TestStickyRouting(t *testing.T) {
+	tracker := health.NewTracker(nil)
+	router := NewRouter(tracker)
+	router.SetAffinity(time.Minute, 10)
+	policy := domain.RoutingPolicy{Strategy: domain.RoutingStrategyRoundRobin}
+	turn := func(conversation, firstMessage string, turns int) *domain.ChatRequest {
+		req := &domain.ChatRequest{APIKeyID: "key-1", ConversationID: conversation}
+		for i := 0; i < turns; i++ {
+			req.Messages = append(req.Messages,
+				domain.Message{Role: "user", Content: []domain.ContentBlock{{Type: "text", Text: firstMessage}}},
+				domain.Message{Role: "assistant", Content: []domain.ContentBlock{{Type: "text", Text: "ok"}}})
+			firstMessage = "and then?"
+		}
+		return req
+	}
+	route := func(req *domain.ChatRequest) string {
+		provider, model, err := router.Route(context.Background(), req, policy, domain.ModelRestrictions{})
+		if err != nil {
+			t.Fatalf("Route: %v", err)
+		}
+		return provider + "/" + model
+	}
+
+	// Round robin would move every request; the turns of a conversation stay put
+	first := route(turn("", "Plan a trip", 1))
+	other := route(turn("", "Write a poem", 1))
+	if first == other {
+		t.Fatalf("expected a different conversation to be routed afresh, both got %s", first)
+	}
+	req := turn("", "Plan a trip", 3)
+	if got := route(req); got != first || !req.RoutingDecision.Sticky {
+		t.Fatalf("expected the third turn to stay on %s, got %s (%+v)", first, got, req.RoutingDecision)
+	}
+
+	// A client conversation ID keys the pin regardless of content
+	pinned := route(turn("conv-1", "Hello", 1))
+	if got := route(turn("conv-1", "Something else", 2)); got != pinned {
+		t.Fatalf("expected conv-1 to stay on %s, got %s", pinned, got)
+	}
+
+	// A failure releases the pin
+	router.ReleaseAffinity(req)
+	req = turn("", "Plan a trip", 4)
+	if route(req); req.RoutingDecision.Sticky {
+		t.Fatalf("expected the conversation to be routed afresh after a failure")
+	}
+
+	// So does the pinned target becoming unhealthy
+	provider, model := router.parseModelID(req.RoutingDecision.Model)
+	for i := 0; i < 10; i++ {
+		tracker.RecordFailure(context.Background(), "", provider, model, "provider_error")
+	}
+	req = turn("", "Plan a trip", 5)
+	if got := route(req); got == provider+"/"+model || req.RoutingDecision.Sticky {
+		t.Fatalf("expected to move off the unhealthy target, got %s", got)
+	}
+}
+
+func TestStreamLatencyStats(t *testing.T) {
+	tracker := health.NewTracker(nil)
+	ctx := context.Background()
+	for i := 1; i <= 20; i++ {
+		tracker.RecordStreamSuccess(ctx, "", "openai", "gpt-4o", 2000, i*100, 50)
+	}
+	tracker.RecordSuccess(ctx, "", "openai", "gpt-4o", 800) // Not streamed
+
+	stats := tracker.Latency("openai", "openai/gpt-4o")
+	if stats.Samples != 21 || stats.P50TimeToFirstTokenMs != 1000 || stats.P95TimeToFirstTokenMs != 1900 {
+		t.Fatalf("unexpected time to first token stats %+v", stats)
+	}
+	if stats.AvgTokensPerSecond != 50 {
+		t.Fatalf("expected unstreamed requests not to count towards throughput, got %+v", stats)
+	}
+}
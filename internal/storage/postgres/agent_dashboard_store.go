@@ -14,12 +14,13 @@ import (
 
 // AgentDashboardStore handles agent dashboard data storage and retrieval
 type AgentDashboardStore struct {
-	db *DB
+	db    *DB
+	reads Querier // Dashboard queries; read replicas when configured
 }
 
 // NewAgentDashboardStore creates a new agent dashboard store
 func NewAgentDashboardStore(db *DB) *AgentDashboardStore {
-	return &AgentDashboardStore{db: db}
+	return &AgentDashboardStore{db: db, reads: db.reader()}
 }
 
 // GetStats retrieves comprehensive dashboard statistics for an agent
@@ -92,7 +93,7 @@ func (s *AgentDashboardStore) getAPIKeyName(ctx context.Context, apiKeyID string
 
 	var name string
 	query := `SELECT name FROM api_keys WHERE id = $1`
-	err := s.db.QueryRowContext(ctx, query, apiKeyID).Scan(&name)
+	err := s.reads.QueryRowContext(ctx, query, apiKeyID).Scan(&name)
 	if err == sql.ErrNoRows {
 		return "Unknown", nil
 	}
@@ -114,6 +115,7 @@ func (s *AgentDashboardStore) getProviderModelUsage(ctx context.Context, apiKeyI
 		FROM usage_records
 		WHERE created_at >= $1
 			AND created_at <= $2
+			AND deleted_at IS NULL
 			AND ($3::uuid IS NULL OR api_key_id = $3::uuid)
 		GROUP BY provider, model
 		ORDER BY request_count DESC
@@ -127,7 +129,7 @@ func (s *AgentDashboardStore) getProviderModelUsage(ctx context.Context, apiKeyI
 		}
 	}
 
-	rows, err := s.db.QueryContext(ctx, query, startTime, endTime, apiKeyUUID)
+	rows, err := s.reads.QueryContext(ctx, query, startTime, endTime, apiKeyUUID)
 	if err != nil {
 		return nil, err
 	}
@@ -156,6 +158,7 @@ func (s *AgentDashboardStore) getTokenMetrics(ctx context.Context, apiKeyID stri
 		FROM usage_records
 		WHERE created_at >= $1
 			AND created_at <= $2
+			AND deleted_at IS NULL
 			AND ($3::uuid IS NULL OR api_key_id = $3::uuid)
 	`
 
@@ -168,7 +171,7 @@ func (s *AgentDashboardStore) getTokenMetrics(ctx context.Context, apiKeyID stri
 	}
 
 	var metrics domain.TokenMetrics
-	err := s.db.QueryRowContext(ctx, query, startTime, endTime, apiKeyUUID).Scan(
+	err := s.reads.QueryRowContext(ctx, query, startTime, endTime, apiKeyUUID).Scan(
 		&metrics.TotalInput,
 		&metrics.TotalOutput,
 		&metrics.TotalThinking,
@@ -189,11 +192,12 @@ func (s *AgentDashboardStore) getTokenMetrics(ctx context.Context, apiKeyID stri
 		FROM usage_records
 		WHERE created_at >= $1
 			AND created_at <= $2
+			AND deleted_at IS NULL
 			AND ($3::uuid IS NULL OR api_key_id = $3::uuid)
 		GROUP BY model
 	`
 
-	rows, err := s.db.QueryContext(ctx, byModelQuery, startTime, endTime, apiKeyUUID)
+	rows, err := s.reads.QueryContext(ctx, byModelQuery, startTime, endTime, apiKeyUUID)
 	if err != nil {
 		return metrics, err
 	}
@@ -235,7 +239,7 @@ func (s *AgentDashboardStore) getCacheStatistics(ctx context.Context, apiKeyID s
 	}
 
 	var stats domain.CacheStatistics
-	err := s.db.QueryRowContext(ctx, query, startTime, endTime, apiKeyUUID).Scan(
+	err := s.reads.QueryRowContext(ctx, query, startTime, endTime, apiKeyUUID).Scan(
 		&stats.TotalHits,
 		&stats.TotalMisses,
 		&stats.TokensSaved,
@@ -278,7 +282,7 @@ func (s *AgentDashboardStore) getToolCallStatistics(ctx context.Context, apiKeyI
 		}
 	}
 
-	rows, err := s.db.QueryContext(ctx, query, startTime, endTime, apiKeyUUID)
+	rows, err := s.reads.QueryContext(ctx, query, startTime, endTime, apiKeyUUID)
 	if err != nil {
 		return nil, err
 	}
@@ -323,7 +327,7 @@ func (s *AgentDashboardStore) getPolicyViolationStats(ctx context.Context, apiKe
 		}
 	}
 
-	rows, err := s.db.QueryContext(ctx, query, startTime, endTime, apiKeyUUID)
+	rows, err := s.reads.QueryContext(ctx, query, startTime, endTime, apiKeyUUID)
 	if err != nil {
 		return nil, err
 	}
@@ -485,7 +489,7 @@ func (s *AgentDashboardStore) GetPolicyViolations(ctx context.Context, apiKeyID
 		}
 	}
 
-	rows, err := s.db.QueryContext(ctx, query, startTime, endTime, apiKeyUUID)
+	rows, err := s.reads.QueryContext(ctx, query, startTime, endTime, apiKeyUUID)
 	if err != nil {
 		return nil, err
 	}
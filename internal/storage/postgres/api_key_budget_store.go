@@ -0,0 +1,50 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+// UpdateAPIKeyBudget sets an API key's spending caps; nil removes them
+func (s *TenantStore) UpdateAPIKeyBudget(ctx context.Context, id string, budget *domain.APIKeyBudget) error {
+	var budgetJSON []byte
+	if budget != nil {
+		var err error
+		if budgetJSON, err = json.Marshal(budget); err != nil {
+			return err
+		}
+	}
+	_, err := s.db.ExecContext(ctx, "UPDATE api_keys SET budget = $2, updated_at = $3 WHERE id = $1", id, budgetJSON, time.Now())
+	return err
+}
+
+// GetAPIKeySpend sums an API key's cost since the start of the day and of the month
+func (s *TenantStore) GetAPIKeySpend(ctx context.Context, keyID string, dayStart, monthStart time.Time) (*domain.APIKeySpend, error) {
+	var spend domain.APIKeySpend
+	err := s.db.QueryRowContext(ctx, `
+		SELECT
+			COALESCE(SUM(cost_usd) FILTER (WHERE created_at >= $2), 0),
+			COALESCE(SUM(cost_usd) FILTER (WHERE created_at >= $3), 0)
+		FROM usage_records
+		WHERE api_key_id = $1 AND created_at >= $3 AND deleted_at IS NULL
+	`, keyID, dayStart, monthStart).Scan(&spend.DailyUSD, &spend.MonthlyUSD)
+	if err != nil {
+		return nil, err
+	}
+	return &spend, nil
+}
+
+// unmarshalAPIKeyBudget decodes the budget column; NULL means no budget
+func unmarshalAPIKeyBudget(data []byte) *domain.APIKeyBudget {
+	if len(data) == 0 {
+		return nil
+	}
+	var budget domain.APIKeyBudget
+	if err := json.Unmarshal(data, &budget); err != nil {
+		return nil
+	}
+	return &budget
+}
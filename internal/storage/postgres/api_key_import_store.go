@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+
+	"modelgate/internal/domain"
+)
+
+// APIKeyHashExists reports whether a key with the hash exists, revoked or not
+func (s *TenantStore) APIKeyHashExists(ctx context.Context, keyHash string) (bool, error) {
+	var exists bool
+	err := s.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM api_keys WHERE key_hash = $1)", keyHash).Scan(&exists)
+	return exists, err
+}
+
+// ListAPIKeyHashes returns the hash of every key by key ID, for exports that
+// move keys to another gateway
+func (s *TenantStore) ListAPIKeyHashes(ctx context.Context) (map[string]string, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT id, key_hash FROM api_keys")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hashes := make(map[string]string)
+	for rows.Next() {
+		var id, hash string
+		if err := rows.Scan(&id, &hash); err != nil {
+			return nil, err
+		}
+		hashes[id] = hash
+	}
+	return hashes, rows.Err()
+}
+
+// ImportAPIKey stores a key issued by another gateway under its existing
+// hash, so clients keep using the secret they have. key.ID is assigned.
+func (s *TenantStore) ImportAPIKey(ctx context.Context, key *domain.APIKey) error {
+	var budgetJSON []byte
+	if key.Budget != nil {
+		var err error
+		if budgetJSON, err = json.Marshal(key.Budget); err != nil {
+			return err
+		}
+	}
+	scopesJSON, err := json.Marshal(nonNilStrings(key.Scopes))
+	if err != nil {
+		return err
+	}
+	cidrsJSON, err := json.Marshal(nonNilStrings(key.AllowedCIDRs))
+	if err != nil {
+		return err
+	}
+	key.ID = uuid.New().String()
+	now := time.Now()
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO api_keys (id, name, key_prefix, key_hash, role_id, group_id, project_id, scopes, allowed_cidrs, expires_at, budget, created_by_email, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $13)
+	`, key.ID, key.Name, key.KeyPrefix, key.KeyHash, nullString(key.RoleID), nullString(key.GroupID), nullString(key.ProjectID),
+		scopesJSON, cidrsJSON, key.ExpiresAt, budgetJSON, key.CreatedByEmail, now)
+	if err != nil {
+		return err
+	}
+	key.CreatedAt, key.UpdatedAt = now, now
+	return nil
+}
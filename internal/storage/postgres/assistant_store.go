@@ -0,0 +1,255 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+// ============================================================================
+// Assistants and runs (Assistants API)
+// ============================================================================
+
+const assistantColumns = `id, api_key_id, name, description, model, instructions, tools, temperature,
+	metadata, created_at, updated_at`
+
+const runColumns = `id, thread_id, assistant_id, api_key_id, status, model, instructions, tools, temperature,
+	required_tool_calls, last_error, prompt_tokens, completion_tokens, metadata,
+	created_at, started_at, expires_at, completed_at, failed_at, cancelled_at`
+
+// CreateAssistant creates an assistant
+func (s *TenantStore) CreateAssistant(ctx context.Context, a *domain.Assistant) error {
+	tools, metadata, err := encodeAssistantJSON(a.Tools, a.Metadata)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	err = s.db.QueryRowContext(ctx, `
+		INSERT INTO assistants (api_key_id, name, description, model, instructions, tools, temperature, metadata, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id
+	`, nullString(a.APIKeyID), a.Name, a.Description, a.Model, a.Instructions, tools, a.Temperature, metadata, now, now).Scan(&a.ID)
+	if err != nil {
+		return fmt.Errorf("insert assistant: %w", err)
+	}
+	a.CreatedAt, a.UpdatedAt = now, now
+	return nil
+}
+
+// UpdateAssistant saves an assistant's settings
+func (s *TenantStore) UpdateAssistant(ctx context.Context, a *domain.Assistant) error {
+	tools, metadata, err := encodeAssistantJSON(a.Tools, a.Metadata)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE assistants SET name = $2, description = $3, model = $4, instructions = $5,
+			tools = $6, temperature = $7, metadata = $8
+		WHERE id = $1
+	`, a.ID, a.Name, a.Description, a.Model, a.Instructions, tools, a.Temperature, metadata)
+	a.UpdatedAt = time.Now()
+	return err
+}
+
+// GetAssistant gets an assistant by ID, or nil if there is none
+func (s *TenantStore) GetAssistant(ctx context.Context, id string) (*domain.Assistant, error) {
+	a, err := scanAssistant(s.db.QueryRowContext(ctx, `SELECT `+assistantColumns+` FROM assistants WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return a, err
+}
+
+// ListAssistants lists assistants, newest first. If apiKeyID is set, only that key's assistants are returned.
+func (s *TenantStore) ListAssistants(ctx context.Context, apiKeyID string, limit int) ([]*domain.Assistant, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+assistantColumns+` FROM assistants
+		WHERE ($1 = '' OR api_key_id::text = $1)
+		ORDER BY created_at DESC
+		LIMIT $2`, apiKeyID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*domain.Assistant
+	for rows.Next() {
+		a, err := scanAssistant(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, a)
+	}
+	return list, rows.Err()
+}
+
+// DeleteAssistant deletes an assistant and its runs. Returns false if it did not exist.
+func (s *TenantStore) DeleteAssistant(ctx context.Context, id string) (bool, error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM assistants WHERE id = $1`, id)
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// CreateRun creates a run
+func (s *TenantStore) CreateRun(ctx context.Context, run *domain.Run) error {
+	tools, metadata, err := encodeAssistantJSON(run.Tools, run.Metadata)
+	if err != nil {
+		return err
+	}
+	run.CreatedAt = time.Now()
+	err = s.db.QueryRowContext(ctx, `
+		INSERT INTO assistant_runs (thread_id, assistant_id, api_key_id, status, model, instructions, tools,
+			temperature, metadata, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id
+	`, run.ThreadID, run.AssistantID, nullString(run.APIKeyID), run.Status, run.Model, run.Instructions, tools,
+		run.Temperature, metadata, run.CreatedAt, run.ExpiresAt).Scan(&run.ID)
+	if err != nil {
+		return fmt.Errorf("insert run: %w", err)
+	}
+	return nil
+}
+
+// UpdateRun saves the progress of a run if its stored status is still
+// expected. Returns false, saving nothing, if another update got there first
+// (the run was cancelled, say).
+func (s *TenantStore) UpdateRun(ctx context.Context, run *domain.Run, expected domain.RunStatus) (bool, error) {
+	var required, lastError []byte
+	var err error
+	if len(run.RequiredToolCalls) > 0 {
+		if required, err = json.Marshal(run.RequiredToolCalls); err != nil {
+			return false, fmt.Errorf("encode tool calls: %w", err)
+		}
+	}
+	if run.LastError != nil {
+		if lastError, err = json.Marshal(run.LastError); err != nil {
+			return false, fmt.Errorf("encode error: %w", err)
+		}
+	}
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE assistant_runs SET status = $3, required_tool_calls = $4, last_error = $5,
+			prompt_tokens = $6, completion_tokens = $7, started_at = $8, expires_at = $9,
+			completed_at = $10, failed_at = $11, cancelled_at = $12
+		WHERE id = $1 AND status = $2
+	`, run.ID, expected, run.Status, required, lastError, run.Usage.PromptTokens, run.Usage.CompletionTokens,
+		run.StartedAt, run.ExpiresAt, run.CompletedAt, run.FailedAt, run.CancelledAt)
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// GetRun gets a run by ID, or nil if there is none
+func (s *TenantStore) GetRun(ctx context.Context, id string) (*domain.Run, error) {
+	run, err := scanRun(s.db.QueryRowContext(ctx, `SELECT `+runColumns+` FROM assistant_runs WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return run, err
+}
+
+// ListRuns lists the runs of a thread, newest first
+func (s *TenantStore) ListRuns(ctx context.Context, threadID string, limit int) ([]*domain.Run, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+runColumns+` FROM assistant_runs
+		WHERE thread_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2`, threadID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []*domain.Run
+	for rows.Next() {
+		run, err := scanRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+func encodeAssistantJSON(tools []domain.AssistantTool, metadata map[string]string) ([]byte, []byte, error) {
+	if tools == nil {
+		tools = []domain.AssistantTool{}
+	}
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+	toolsJSON, err := json.Marshal(tools)
+	if err != nil {
+		return nil, nil, fmt.Errorf("encode tools: %w", err)
+	}
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, nil, fmt.Errorf("encode metadata: %w", err)
+	}
+	return toolsJSON, metadataJSON, nil
+}
+
+func scanAssistant(row rowScanner) (*domain.Assistant, error) {
+	var a domain.Assistant
+	var apiKeyID sql.NullString
+	var temperature sql.NullFloat64
+	var tools, metadata []byte
+	err := row.Scan(&a.ID, &apiKeyID, &a.Name, &a.Description, &a.Model, &a.Instructions, &tools,
+		&temperature, &metadata, &a.CreatedAt, &a.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	a.APIKeyID = apiKeyID.String
+	if temperature.Valid {
+		t := float32(temperature.Float64)
+		a.Temperature = &t
+	}
+	json.Unmarshal(tools, &a.Tools)
+	json.Unmarshal(metadata, &a.Metadata)
+	return &a, nil
+}
+
+func scanRun(row rowScanner) (*domain.Run, error) {
+	var run domain.Run
+	var apiKeyID sql.NullString
+	var temperature sql.NullFloat64
+	var tools, required, lastError, metadata []byte
+	var startedAt, completedAt, failedAt, cancelledAt sql.NullTime
+	err := row.Scan(&run.ID, &run.ThreadID, &run.AssistantID, &apiKeyID, &run.Status, &run.Model, &run.Instructions,
+		&tools, &temperature, &required, &lastError, &run.Usage.PromptTokens, &run.Usage.CompletionTokens, &metadata,
+		&run.CreatedAt, &startedAt, &run.ExpiresAt, &completedAt, &failedAt, &cancelledAt)
+	if err != nil {
+		return nil, err
+	}
+	run.APIKeyID = apiKeyID.String
+	run.Usage.TotalTokens = run.Usage.PromptTokens + run.Usage.CompletionTokens
+	if temperature.Valid {
+		t := float32(temperature.Float64)
+		run.Temperature = &t
+	}
+	json.Unmarshal(tools, &run.Tools)
+	json.Unmarshal(metadata, &run.Metadata)
+	if len(required) > 0 {
+		json.Unmarshal(required, &run.RequiredToolCalls)
+	}
+	if len(lastError) > 0 {
+		run.LastError = &domain.RunError{}
+		json.Unmarshal(lastError, run.LastError)
+	}
+	for _, t := range []struct {
+		src sql.NullTime
+		dst **time.Time
+	}{{startedAt, &run.StartedAt}, {completedAt, &run.CompletedAt}, {failedAt, &run.FailedAt}, {cancelledAt, &run.CancelledAt}} {
+		if t.src.Valid {
+			at := t.src.Time
+			*t.dst = &at
+		}
+	}
+	return &run, nil
+}
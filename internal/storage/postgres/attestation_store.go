@@ -0,0 +1,169 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+// ============================================================================
+// Response attestations
+// ============================================================================
+
+const attestationKeyColumns = `id, public_key, created_by, created_at, retired_at`
+
+func scanAttestationKey(row interface{ Scan(...any) error }) (*domain.AttestationKey, error) {
+	var key domain.AttestationKey
+	var createdBy sql.NullString
+	var retiredAt sql.NullTime
+	if err := row.Scan(&key.ID, &key.PublicKey, &createdBy, &key.CreatedAt, &retiredAt); err != nil {
+		return nil, err
+	}
+	key.CreatedBy = createdBy.String
+	if retiredAt.Valid {
+		key.RetiredAt = &retiredAt.Time
+	}
+	return &key, nil
+}
+
+// CreateAttestationKey stores a new signing key and retires the one it
+// replaces. The seed is sealed in an envelope when encryption is configured.
+func (s *TenantStore) CreateAttestationKey(ctx context.Context, key *domain.AttestationKey, seed []byte) error {
+	private := seed
+	if s.encryption != nil {
+		env, err := s.encryption.Seal(seed)
+		if err != nil {
+			return fmt.Errorf("encrypt attestation key: %w", err)
+		}
+		if private, err = json.Marshal(env); err != nil {
+			return err
+		}
+	}
+
+	tx, err := s.beginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `UPDATE attestation_keys SET retired_at = $1 WHERE retired_at IS NULL`, key.CreatedAt); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO attestation_keys (id, public_key, private_key, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, key.ID, key.PublicKey, private, nullString(key.CreatedBy), key.CreatedAt); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetActiveAttestationKey returns the key currently signing and its seed, or
+// nil when no key was created yet
+func (s *TenantStore) GetActiveAttestationKey(ctx context.Context) (*domain.AttestationKey, []byte, error) {
+	var private []byte
+	var retiredAt sql.NullTime
+	var createdBy sql.NullString
+	var key domain.AttestationKey
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, public_key, private_key, created_by, created_at, retired_at
+		FROM attestation_keys WHERE retired_at IS NULL
+	`).Scan(&key.ID, &key.PublicKey, &private, &createdBy, &key.CreatedAt, &retiredAt)
+	if err == sql.ErrNoRows {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	key.CreatedBy = createdBy.String
+
+	env, ok := parseAuthEnvelope(private)
+	if !ok {
+		return &key, private, nil
+	}
+	if s.encryption == nil {
+		return nil, nil, fmt.Errorf("attestation key is encrypted but no encryption key is configured")
+	}
+	seed, err := s.encryption.Open(env)
+	if err != nil {
+		return nil, nil, fmt.Errorf("decrypt attestation key: %w", err)
+	}
+	return &key, seed, nil
+}
+
+// ListAttestationKeys returns every signing key, newest first, without its
+// private half
+func (s *TenantStore) ListAttestationKeys(ctx context.Context) ([]domain.AttestationKey, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+attestationKeyColumns+` FROM attestation_keys ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []domain.AttestationKey
+	for rows.Next() {
+		key, err := scanAttestationKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+	return keys, rows.Err()
+}
+
+// InsertResponseAttestations stores signed attestations. An attestation whose
+// request already has one is ignored.
+func (s *TenantStore) InsertResponseAttestations(ctx context.Context, atts []domain.ResponseAttestation) error {
+	tx, err := s.beginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO response_attestations (request_id, key_id, request_hash, response_hash, policy_version, signed_at, signature)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (request_id) DO NOTHING
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, a := range atts {
+		if _, err := stmt.ExecContext(ctx, a.RequestID, a.KeyID, a.RequestHash, a.ResponseHash,
+			a.PolicyVersion, a.SignedAt, a.Signature); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetResponseAttestation returns the attestation stored for a request, or nil
+func (s *TenantStore) GetResponseAttestation(ctx context.Context, requestID string) (*domain.ResponseAttestation, error) {
+	var a domain.ResponseAttestation
+	err := s.db.QueryRowContext(ctx, `
+		SELECT request_id, key_id, request_hash, response_hash, policy_version, signed_at, signature
+		FROM response_attestations WHERE request_id = $1
+	`, requestID).Scan(&a.RequestID, &a.KeyID, &a.RequestHash, &a.ResponseHash, &a.PolicyVersion, &a.SignedAt, &a.Signature)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// DeleteResponseAttestations deletes attestations signed before the cutoff
+func (s *TenantStore) DeleteResponseAttestations(ctx context.Context, cutoff time.Time) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM response_attestations WHERE signed_at < $1`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,152 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+// ============================================================================
+// Audit log export (cursor pages and export jobs)
+// ============================================================================
+
+// ListAuditLogsPage returns up to limit audit logs matching the filter, oldest
+// first, starting after the cursor (nil starts at the beginning)
+func (s *TenantStore) ListAuditLogsPage(ctx context.Context, filter domain.AuditLogFilter, after *domain.AuditLogCursor, limit int) ([]domain.AuditLog, error) {
+	where, args := auditLogConditions(filter)
+	if after != nil {
+		args = append(args, after.Timestamp, after.ID)
+		where += fmt.Sprintf(" AND (timestamp, id) > ($%d, $%d::uuid)", len(args)-1, len(args))
+	}
+	args = append(args, limit)
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, timestamp, action, resource_type, resource_id, resource_name,
+			   actor_id, actor_email, actor_type, ip_address, user_agent,
+			   details, old_value, new_value, status, error_message
+		FROM audit_logs
+		WHERE `+where+`
+		ORDER BY timestamp, id
+		LIMIT $`+fmt.Sprint(len(args)), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var logs []domain.AuditLog
+	for rows.Next() {
+		log, err := scanAuditLog(rows)
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, *log)
+	}
+	return logs, rows.Err()
+}
+
+const auditExportJobColumns = `id, status, format, filter, requested_by, row_count, size_bytes,
+	object_key, download_token, error, created_at, completed_at, expires_at`
+
+// CreateAuditExportJob stores a new export job
+func (s *TenantStore) CreateAuditExportJob(ctx context.Context, job *domain.AuditExportJob) error {
+	filterJSON, _ := json.Marshal(job.Filter)
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO audit_export_jobs (id, status, format, filter, requested_by, download_token, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, job.ID, job.Status, job.Format, filterJSON,
+		sql.NullString{String: job.RequestedBy, Valid: job.RequestedBy != ""},
+		job.DownloadToken, job.CreatedAt)
+	return err
+}
+
+// UpdateAuditExportJob stores a job's progress and result. content is the
+// exported file when it is kept in the database for download, otherwise nil.
+func (s *TenantStore) UpdateAuditExportJob(ctx context.Context, job *domain.AuditExportJob, content []byte) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE audit_export_jobs
+		SET status = $2, row_count = $3, size_bytes = $4, object_key = $5, error = $6,
+		    completed_at = $7, expires_at = $8, content = $9
+		WHERE id = $1
+	`, job.ID, job.Status, job.RowCount, job.SizeBytes,
+		sql.NullString{String: job.ObjectKey, Valid: job.ObjectKey != ""},
+		sql.NullString{String: job.Error, Valid: job.Error != ""},
+		job.CompletedAt, job.ExpiresAt, content)
+	return err
+}
+
+// GetAuditExportJob retrieves an export job by ID
+func (s *TenantStore) GetAuditExportJob(ctx context.Context, id string) (*domain.AuditExportJob, error) {
+	job, err := scanAuditExportJob(s.db.QueryRowContext(ctx,
+		`SELECT `+auditExportJobColumns+` FROM audit_export_jobs WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return job, err
+}
+
+// ListAuditExportJobs returns the most recent export jobs
+func (s *TenantStore) ListAuditExportJobs(ctx context.Context, limit int) ([]*domain.AuditExportJob, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+auditExportJobColumns+` FROM audit_export_jobs ORDER BY created_at DESC LIMIT $1`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []*domain.AuditExportJob
+	for rows.Next() {
+		job, err := scanAuditExportJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+// GetAuditExportContent returns the exported file kept for a job, or nil
+func (s *TenantStore) GetAuditExportContent(ctx context.Context, id string) ([]byte, error) {
+	var content []byte
+	err := s.db.QueryRowContext(ctx, `SELECT content FROM audit_export_jobs WHERE id = $1`, id).Scan(&content)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return content, err
+}
+
+// PurgeExpiredAuditExports drops the stored files of exports that expired before the given time
+func (s *TenantStore) PurgeExpiredAuditExports(ctx context.Context, before time.Time) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE audit_export_jobs SET content = NULL
+		WHERE expires_at < $1 AND content IS NOT NULL
+	`, before)
+	return err
+}
+
+func scanAuditExportJob(row interface{ Scan(...any) error }) (*domain.AuditExportJob, error) {
+	var job domain.AuditExportJob
+	var filterJSON []byte
+	var requestedBy, objectKey, downloadToken, errMsg sql.NullString
+	var completedAt, expiresAt sql.NullTime
+
+	if err := row.Scan(&job.ID, &job.Status, &job.Format, &filterJSON, &requestedBy, &job.RowCount,
+		&job.SizeBytes, &objectKey, &downloadToken, &errMsg, &job.CreatedAt, &completedAt, &expiresAt); err != nil {
+		return nil, err
+	}
+	json.Unmarshal(filterJSON, &job.Filter)
+	job.RequestedBy = requestedBy.String
+	job.ObjectKey = objectKey.String
+	job.DownloadToken = downloadToken.String
+	job.Error = errMsg.String
+	if completedAt.Valid {
+		job.CompletedAt = &completedAt.Time
+	}
+	if expiresAt.Valid {
+		job.ExpiresAt = &expiresAt.Time
+	}
+	return &job, nil
+}
@@ -0,0 +1,134 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+// ============================================================================
+// Batches (provider-native batch jobs)
+// ============================================================================
+
+const batchColumns = `id, api_key_id, endpoint, input_file_id, output_file_id, completion_window,
+	status, provider, model, provider_batch_id, total_requests, completed_requests, failed_requests,
+	errors, metadata, completed_at, created_at, updated_at`
+
+// CreateBatch creates a new batch job
+func (s *TenantStore) CreateBatch(ctx context.Context, batch *domain.Batch) error {
+	metadataJSON, err := json.Marshal(batch.Metadata)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if batch.Status == "" {
+		batch.Status = domain.BatchStatusValidating
+	}
+	batch.CreatedAt = now
+	batch.UpdatedAt = now
+
+	query := `
+		INSERT INTO batches (
+			api_key_id, endpoint, input_file_id, completion_window, status, provider, model,
+			total_requests, metadata, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id
+	`
+	return s.db.QueryRowContext(ctx, query,
+		nullString(batch.APIKeyID), batch.Endpoint, nullString(batch.InputFileID), batch.CompletionWindow,
+		batch.Status, string(batch.Provider), batch.Model, batch.RequestCounts.Total, metadataJSON, now, now,
+	).Scan(&batch.ID)
+}
+
+// UpdateBatch saves the mutable state of a batch (status, provider ID, counts, output and errors)
+func (s *TenantStore) UpdateBatch(ctx context.Context, batch *domain.Batch) error {
+	batch.UpdatedAt = time.Now()
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE batches SET
+			status = $2, provider_batch_id = $3, output_file_id = $4,
+			total_requests = $5, completed_requests = $6, failed_requests = $7,
+			errors = $8, completed_at = $9
+		WHERE id = $1
+	`, batch.ID, batch.Status, nullString(batch.ProviderBatchID), nullString(batch.OutputFileID),
+		batch.RequestCounts.Total, batch.RequestCounts.Completed, batch.RequestCounts.Failed,
+		nullString(batch.Errors), batch.CompletedAt,
+	)
+	return err
+}
+
+// GetBatch gets a batch by ID
+func (s *TenantStore) GetBatch(ctx context.Context, id string) (*domain.Batch, error) {
+	query := `SELECT ` + batchColumns + ` FROM batches WHERE id = $1`
+	batch, err := scanBatch(s.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return batch, err
+}
+
+// ListBatches lists batches, newest first. If apiKeyID is set, only that key's batches are returned.
+func (s *TenantStore) ListBatches(ctx context.Context, apiKeyID string, limit int) ([]*domain.Batch, error) {
+	query := `SELECT ` + batchColumns + ` FROM batches
+		WHERE ($1 = '' OR api_key_id::text = $1)
+		ORDER BY created_at DESC
+		LIMIT $2`
+	return s.queryBatches(ctx, query, apiKeyID, limit)
+}
+
+// ListActiveBatches lists batches that have been submitted to a provider and are not yet finished
+func (s *TenantStore) ListActiveBatches(ctx context.Context) ([]*domain.Batch, error) {
+	query := `SELECT ` + batchColumns + ` FROM batches
+		WHERE status IN ('in_progress', 'finalizing', 'cancelling') AND provider_batch_id IS NOT NULL
+		ORDER BY created_at`
+	return s.queryBatches(ctx, query)
+}
+
+func (s *TenantStore) queryBatches(ctx context.Context, query string, args ...any) ([]*domain.Batch, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var batches []*domain.Batch
+	for rows.Next() {
+		batch, err := scanBatch(rows)
+		if err != nil {
+			return nil, err
+		}
+		batches = append(batches, batch)
+	}
+	return batches, rows.Err()
+}
+
+func scanBatch(row rowScanner) (*domain.Batch, error) {
+	var batch domain.Batch
+	var apiKeyID, inputFileID, outputFileID, providerBatchID, errors sql.NullString
+	var provider string
+	var metadataJSON []byte
+	var completedAt sql.NullTime
+	err := row.Scan(
+		&batch.ID, &apiKeyID, &batch.Endpoint, &inputFileID, &outputFileID, &batch.CompletionWindow,
+		&batch.Status, &provider, &batch.Model, &providerBatchID,
+		&batch.RequestCounts.Total, &batch.RequestCounts.Completed, &batch.RequestCounts.Failed,
+		&errors, &metadataJSON, &completedAt, &batch.CreatedAt, &batch.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	batch.APIKeyID = apiKeyID.String
+	batch.InputFileID = inputFileID.String
+	batch.OutputFileID = outputFileID.String
+	batch.ProviderBatchID = providerBatchID.String
+	batch.Errors = errors.String
+	batch.Provider = domain.Provider(provider)
+	json.Unmarshal(metadataJSON, &batch.Metadata)
+	if completedAt.Valid {
+		batch.CompletedAt = &completedAt.Time
+	}
+	return &batch, nil
+}
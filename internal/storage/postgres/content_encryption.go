@@ -0,0 +1,223 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"modelgate/internal/crypto"
+	"modelgate/internal/domain"
+)
+
+// capturedContentKeys are the usage record metadata fields holding request
+// and response content, which are encrypted at rest
+var capturedContentKeys = []string{"prompt", "request", "response"}
+
+// ContentCipher returns the cipher for the tenant's data key, creating and
+// storing the key on first use. It returns nil when encryption is not
+// configured, in which case captured content is stored as is.
+func (s *TenantStore) ContentCipher(ctx context.Context) (*crypto.ContentCipher, error) {
+	if s.encryption == nil {
+		return nil, nil
+	}
+	s.shared.contentMu.Lock()
+	defer s.shared.contentMu.Unlock()
+	if s.shared.contentCipher != nil {
+		return s.shared.contentCipher, nil
+	}
+
+	env, err := s.loadContentKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if env == nil {
+		if err := s.createContentKey(ctx); err != nil {
+			return nil, err
+		}
+		// Another instance may have stored a key first; everyone uses the oldest
+		if env, err = s.loadContentKey(ctx); err != nil {
+			return nil, err
+		}
+		if env == nil {
+			return nil, fmt.Errorf("content key was not stored")
+		}
+	}
+
+	dataKey, err := s.encryption.Open(env)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt content key: %w", err)
+	}
+	if s.shared.contentCipher, err = crypto.NewContentCipher(dataKey); err != nil {
+		return nil, err
+	}
+	return s.shared.contentCipher, nil
+}
+
+func (s *TenantStore) loadContentKey(ctx context.Context) (*crypto.Envelope, error) {
+	var data []byte
+	err := s.db.QueryRowContext(ctx, `SELECT envelope FROM content_keys ORDER BY created_at, key_id LIMIT 1`).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load content key: %w", err)
+	}
+	var env crypto.Envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("invalid content key: %w", err)
+	}
+	return &env, nil
+}
+
+func (s *TenantStore) createContentKey(ctx context.Context) error {
+	dataKey, err := crypto.GenerateKey(32)
+	if err != nil {
+		return err
+	}
+	c, err := crypto.NewContentCipher(dataKey)
+	if err != nil {
+		return err
+	}
+	env, err := s.encryption.Seal(dataKey)
+	if err != nil {
+		return fmt.Errorf("encrypt content key: %w", err)
+	}
+	data, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO content_keys (key_id, envelope) VALUES ($1, $2)
+		ON CONFLICT (key_id) DO NOTHING
+	`, c.KeyID(), data)
+	return err
+}
+
+// sealCapturedContent returns a copy of usage record metadata with the
+// captured content fields encrypted. Metadata is returned unchanged when
+// encryption is not configured.
+func (s *TenantStore) sealCapturedContent(ctx context.Context, metadata map[string]any) (map[string]any, error) {
+	c, err := s.ContentCipher(ctx)
+	if err != nil || c == nil {
+		return metadata, err
+	}
+	sealed := make(map[string]any, len(metadata))
+	for k, v := range metadata {
+		sealed[k] = v
+	}
+	for _, key := range capturedContentKeys {
+		v, ok := sealed[key]
+		if !ok || v == nil {
+			continue
+		}
+		plain, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("encode %s: %w", key, err)
+		}
+		if sealed[key], err = c.Seal(plain); err != nil {
+			return nil, fmt.Errorf("encrypt %s: %w", key, err)
+		}
+	}
+	return sealed, nil
+}
+
+// OpenCapturedContent decrypts the captured content of a usage record in
+// place. Usage records are read with their content still encrypted, so
+// callers decide who gets to see it. Fields that can't be decrypted are
+// dropped.
+func (s *TenantStore) OpenCapturedContent(ctx context.Context, record *domain.UsageRecord) error {
+	if record == nil || record.Metadata == nil {
+		return nil
+	}
+	var c *crypto.ContentCipher
+	for _, key := range capturedContentKeys {
+		sealed, ok := record.Metadata[key].(string)
+		if !ok || !crypto.IsSealed(sealed) {
+			continue
+		}
+		if c == nil {
+			var err error
+			if c, err = s.ContentCipher(ctx); err != nil {
+				return err
+			}
+			if c == nil {
+				return fmt.Errorf("captured content is encrypted but no encryption key is configured")
+			}
+		}
+		plain, err := c.Open(sealed)
+		var v any
+		if err == nil {
+			err = json.Unmarshal(plain, &v)
+		}
+		if err != nil {
+			slog.Warn("Failed to decrypt captured content", "usage_record", record.ID, "field", key, "error", err)
+			delete(record.Metadata, key)
+			continue
+		}
+		record.Metadata[key] = v
+	}
+	return nil
+}
+
+// ReencryptContentKeys re-wraps the tenant data keys with the current primary
+// key. Content sealed with a data key is untouched. It returns the number of
+// keys updated.
+func (s *TenantStore) ReencryptContentKeys(ctx context.Context) (int, error) {
+	if s.encryption == nil {
+		return 0, nil
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT key_id, envelope FROM content_keys`)
+	if err != nil {
+		return 0, err
+	}
+	type storedKey struct {
+		id   string
+		data []byte
+	}
+	var stored []storedKey
+	for rows.Next() {
+		var k storedKey
+		if err := rows.Scan(&k.id, &k.data); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		stored = append(stored, k)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	updated := 0
+	for _, k := range stored {
+		var env crypto.Envelope
+		if err := json.Unmarshal(k.data, &env); err != nil {
+			return updated, fmt.Errorf("content key %s: %w", k.id, err)
+		}
+		changed, err := s.encryption.Rewrap(&env)
+		if err != nil {
+			return updated, fmt.Errorf("content key %s: %w", k.id, err)
+		}
+		if !changed {
+			continue
+		}
+		data, err := json.Marshal(&env)
+		if err != nil {
+			return updated, err
+		}
+		res, err := s.db.ExecContext(ctx, `
+			UPDATE content_keys SET envelope = $2
+			WHERE key_id = $1 AND envelope = $3::jsonb
+		`, k.id, data, k.data)
+		if err != nil {
+			return updated, fmt.Errorf("content key %s: %w", k.id, err)
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			updated++
+		}
+	}
+	return updated, nil
+}
@@ -0,0 +1,177 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+// ============================================================================
+// Custom Domains
+// ============================================================================
+
+// sealSecret seals data in an envelope when encryption is configured
+func (s *TenantStore) sealSecret(data []byte, what string) ([]byte, error) {
+	if s.encryption == nil || data == nil {
+		return data, nil
+	}
+	env, err := s.encryption.Seal(data)
+	if err != nil {
+		return nil, fmt.Errorf("encrypt %s: %w", what, err)
+	}
+	return json.Marshal(env)
+}
+
+// openSecret reads a value written by sealSecret, which is either an envelope
+// or plain data written before encryption was enabled
+func (s *TenantStore) openSecret(data []byte, what string) ([]byte, error) {
+	env, ok := parseAuthEnvelope(data)
+	if !ok {
+		return data, nil
+	}
+	if s.encryption == nil {
+		return nil, fmt.Errorf("%s is encrypted but no encryption key is configured", what)
+	}
+	plain, err := s.encryption.Open(env)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt %s: %w", what, err)
+	}
+	return plain, nil
+}
+
+// ListCustomDomains returns every custom domain with its uploaded key, by hostname
+func (s *TenantStore) ListCustomDomains(ctx context.Context) ([]*domain.CustomDomain, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, hostname, tenant_slug, cert_source, COALESCE(cert_pem, ''), key_pem,
+		       cert_expires_at, enabled, COALESCE(created_by, ''), created_at, updated_at
+		FROM custom_domains
+		ORDER BY hostname
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var domains []*domain.CustomDomain
+	for rows.Next() {
+		var d domain.CustomDomain
+		var key []byte
+		var expiresAt sql.NullTime
+		if err := rows.Scan(&d.ID, &d.Hostname, &d.TenantSlug, &d.CertSource, &d.CertPEM, &key,
+			&expiresAt, &d.Enabled, &d.CreatedBy, &d.CreatedAt, &d.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if expiresAt.Valid {
+			d.CertExpiresAt = &expiresAt.Time
+		}
+		if key != nil {
+			plain, err := s.openSecret(key, "custom domain key")
+			if err != nil {
+				return nil, fmt.Errorf("custom domain %s: %w", d.Hostname, err)
+			}
+			d.KeyPEM = string(plain)
+		}
+		domains = append(domains, &d)
+	}
+	return domains, rows.Err()
+}
+
+// CreateCustomDomain stores a new custom domain and fills in its ID and
+// timestamps. Returns false when the hostname is already registered.
+func (s *TenantStore) CreateCustomDomain(ctx context.Context, d *domain.CustomDomain) (bool, error) {
+	key, err := s.customDomainKey(d)
+	if err != nil {
+		return false, err
+	}
+	err = s.db.QueryRowContext(ctx, `
+		INSERT INTO custom_domains (hostname, tenant_slug, cert_source, cert_pem, key_pem, cert_expires_at, enabled, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (hostname) DO NOTHING
+		RETURNING id, created_at, updated_at
+	`, d.Hostname, d.TenantSlug, string(d.CertSource), nullString(d.CertPEM), key, d.CertExpiresAt, d.Enabled,
+		nullString(d.CreatedBy)).Scan(&d.ID, &d.CreatedAt, &d.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("create custom domain: %w", err)
+	}
+	return true, nil
+}
+
+// UpdateCustomDomain saves a custom domain's tenant, certificate and state.
+// Returns false when it doesn't exist.
+func (s *TenantStore) UpdateCustomDomain(ctx context.Context, d *domain.CustomDomain) (bool, error) {
+	key, err := s.customDomainKey(d)
+	if err != nil {
+		return false, err
+	}
+	err = s.db.QueryRowContext(ctx, `
+		UPDATE custom_domains SET
+			tenant_slug = $2, cert_source = $3, cert_pem = $4, key_pem = $5,
+			cert_expires_at = $6, enabled = $7, updated_at = NOW()
+		WHERE id = $1
+		RETURNING updated_at
+	`, d.ID, d.TenantSlug, string(d.CertSource), nullString(d.CertPEM), key, d.CertExpiresAt, d.Enabled).Scan(&d.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("update custom domain: %w", err)
+	}
+	return true, nil
+}
+
+// customDomainKey returns the stored form of a domain's uploaded key
+func (s *TenantStore) customDomainKey(d *domain.CustomDomain) ([]byte, error) {
+	if d.KeyPEM == "" {
+		return nil, nil
+	}
+	return s.sealSecret([]byte(d.KeyPEM), "custom domain key")
+}
+
+// DeleteCustomDomain removes a custom domain. Returns false when it doesn't exist.
+func (s *TenantStore) DeleteCustomDomain(ctx context.Context, id string) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM custom_domains WHERE id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("delete custom domain: %w", err)
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// GetACMECache returns an ACME cache entry, or nil when there is none
+func (s *TenantStore) GetACMECache(ctx context.Context, key string) ([]byte, error) {
+	var data []byte
+	err := s.db.QueryRowContext(ctx, `SELECT data FROM acme_cache WHERE key = $1`, key).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.openSecret(data, "ACME cache entry")
+}
+
+// PutACMECache stores an ACME cache entry
+func (s *TenantStore) PutACMECache(ctx context.Context, key string, data []byte) error {
+	sealed, err := s.sealSecret(data, "ACME cache entry")
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO acme_cache (key, data, updated_at) VALUES ($1, $2, $3)
+		ON CONFLICT (key) DO UPDATE SET data = EXCLUDED.data, updated_at = EXCLUDED.updated_at
+	`, key, sealed, time.Now())
+	return err
+}
+
+// DeleteACMECache removes an ACME cache entry
+func (s *TenantStore) DeleteACMECache(ctx context.Context, key string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM acme_cache WHERE key = $1`, key)
+	return err
+}
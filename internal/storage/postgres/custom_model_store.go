@@ -0,0 +1,117 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"modelgate/internal/domain"
+)
+
+// ============================================================================
+// Custom Models
+// ============================================================================
+
+const customModelColumns = `id, model_id, name, base_url, upstream_model, context_window, max_output_tokens,
+	input_cost_per_1m, output_cost_per_1m, supports_tools, supports_vision, supports_reasoning,
+	supports_streaming, enabled, created_at, updated_at`
+
+func scanCustomModel(row interface{ Scan(...any) error }) (*domain.CustomModel, error) {
+	var m domain.CustomModel
+	if err := row.Scan(&m.ID, &m.ModelID, &m.Name, &m.BaseURL, &m.UpstreamModel, &m.ContextWindow, &m.MaxOutputTokens,
+		&m.InputCostPer1M, &m.OutputCostPer1M, &m.SupportsTools, &m.SupportsVision, &m.SupportsReasoning,
+		&m.SupportsStreaming, &m.Enabled, &m.CreatedAt, &m.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// CreateCustomModel inserts a custom model, filling in its ID and timestamps
+func (s *TenantStore) CreateCustomModel(ctx context.Context, m *domain.CustomModel) error {
+	return s.db.QueryRowContext(ctx, `
+		INSERT INTO custom_models (model_id, name, base_url, upstream_model, context_window, max_output_tokens,
+			input_cost_per_1m, output_cost_per_1m, supports_tools, supports_vision, supports_reasoning,
+			supports_streaming, enabled)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING id, created_at, updated_at
+	`, m.ModelID, m.Name, m.BaseURL, m.UpstreamModel, m.ContextWindow, m.MaxOutputTokens,
+		m.InputCostPer1M, m.OutputCostPer1M, m.SupportsTools, m.SupportsVision, m.SupportsReasoning,
+		m.SupportsStreaming, m.Enabled).Scan(&m.ID, &m.CreatedAt, &m.UpdatedAt)
+}
+
+// GetCustomModel returns a custom model, or nil if it does not exist
+func (s *TenantStore) GetCustomModel(ctx context.Context, id string) (*domain.CustomModel, error) {
+	m, err := scanCustomModel(s.db.QueryRowContext(ctx, `
+		SELECT `+customModelColumns+`
+		FROM custom_models
+		WHERE id = $1
+	`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return m, err
+}
+
+// ListCustomModels returns all custom models ordered by model ID
+func (s *TenantStore) ListCustomModels(ctx context.Context) ([]*domain.CustomModel, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+customModelColumns+`
+		FROM custom_models
+		ORDER BY model_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var models []*domain.CustomModel
+	for rows.Next() {
+		m, err := scanCustomModel(rows)
+		if err != nil {
+			return nil, err
+		}
+		models = append(models, m)
+	}
+	return models, rows.Err()
+}
+
+// UpdateCustomModel saves a custom model's fields
+func (s *TenantStore) UpdateCustomModel(ctx context.Context, m *domain.CustomModel) error {
+	return s.db.QueryRowContext(ctx, `
+		UPDATE custom_models
+		SET model_id = $2, name = $3, base_url = $4, upstream_model = $5, context_window = $6,
+		    max_output_tokens = $7, input_cost_per_1m = $8, output_cost_per_1m = $9,
+		    supports_tools = $10, supports_vision = $11, supports_reasoning = $12,
+		    supports_streaming = $13, enabled = $14, updated_at = NOW()
+		WHERE id = $1
+		RETURNING updated_at
+	`, m.ID, m.ModelID, m.Name, m.BaseURL, m.UpstreamModel, m.ContextWindow,
+		m.MaxOutputTokens, m.InputCostPer1M, m.OutputCostPer1M,
+		m.SupportsTools, m.SupportsVision, m.SupportsReasoning,
+		m.SupportsStreaming, m.Enabled).Scan(&m.UpdatedAt)
+}
+
+// DeleteCustomModel deletes a custom model, returning false if it did not exist
+func (s *TenantStore) DeleteCustomModel(ctx context.Context, id string) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM custom_models WHERE id = $1`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// loadCustomModels attaches the registered models to the custom provider's config
+func (s *TenantStore) loadCustomModels(ctx context.Context, config *domain.ProviderConfig) error {
+	if config.Provider != domain.ProviderCustom {
+		return nil
+	}
+	models, err := s.ListCustomModels(ctx)
+	if err != nil {
+		return err
+	}
+	config.CustomModels = make([]domain.CustomModel, len(models))
+	for i, m := range models {
+		config.CustomModels[i] = *m
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"modelgate/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ============================================================================
+// Data-plane audit events
+// ============================================================================
+
+// dataPlaneAuditPartition names the partition holding a month of events
+func dataPlaneAuditPartition(month time.Time) string {
+	return fmt.Sprintf("data_plane_audit_events_y%04dm%02d", month.Year(), int(month.Month()))
+}
+
+// GetDataPlaneAuditSettings returns the data-plane audit settings, or nil if
+// they were never set
+func (s *TenantStore) GetDataPlaneAuditSettings(ctx context.Context) (*domain.DataPlaneAuditSettings, error) {
+	var settings domain.DataPlaneAuditSettings
+	err := s.db.QueryRowContext(ctx, `
+		SELECT enabled, store_events, webhook_url, COALESCE(updated_by, ''), updated_at
+		FROM data_plane_audit_settings
+	`).Scan(&settings.Enabled, &settings.StoreEvents, &settings.WebhookURL, &settings.UpdatedBy, &settings.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// SaveDataPlaneAuditSettings replaces the data-plane audit settings
+func (s *TenantStore) SaveDataPlaneAuditSettings(ctx context.Context, settings *domain.DataPlaneAuditSettings) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO data_plane_audit_settings (id, enabled, store_events, webhook_url, updated_by, updated_at)
+		VALUES (TRUE, $1, $2, $3, $4, $5)
+		ON CONFLICT (id) DO UPDATE SET
+			enabled = EXCLUDED.enabled,
+			store_events = EXCLUDED.store_events,
+			webhook_url = EXCLUDED.webhook_url,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = EXCLUDED.updated_at
+	`, settings.Enabled, settings.StoreEvents, settings.WebhookURL, nullString(settings.UpdatedBy), settings.UpdatedAt)
+	return err
+}
+
+// InsertDataPlaneAuditEvents writes a batch of events in one transaction. The
+// partitions of their months must exist.
+func (s *TenantStore) InsertDataPlaneAuditEvents(ctx context.Context, events []domain.DataPlaneAuditEvent) error {
+	tx, err := s.beginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO data_plane_audit_events (
+			id, created_at, request_id, method, path, status_code, latency_ms,
+			api_key_id, role_id, project_id, client_ip, model, served_model,
+			policy_decision, policy_code, tools_allowed, tools_removed, params_clamped,
+			cache_status, routing_strategy
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, e := range events {
+		if e.ID == "" {
+			e.ID = uuid.New().String()
+		}
+		if _, err := stmt.ExecContext(ctx,
+			e.ID, e.CreatedAt, e.RequestID, e.Method, e.Path, e.StatusCode, e.LatencyMs,
+			nullString(e.APIKeyID), e.RoleID, e.ProjectID, e.ClientIP, e.Model, e.ServedModel,
+			e.PolicyDecision, e.PolicyCode, pq.Array(nonNilStrings(e.ToolsAllowed)), pq.Array(nonNilStrings(e.ToolsRemoved)),
+			pq.Array(nonNilStrings(e.ParamsClamped)), e.CacheStatus, e.RoutingStrategy,
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ListDataPlaneAuditEvents returns matching events, newest first
+func (s *TenantStore) ListDataPlaneAuditEvents(ctx context.Context, filter domain.DataPlaneAuditFilter) ([]domain.DataPlaneAuditEvent, error) {
+	var conds []string
+	var args []any
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+	if filter.APIKeyID != "" {
+		add("api_key_id = $%d", filter.APIKeyID)
+	}
+	if filter.Model != "" {
+		add("(model = $%[1]d OR served_model = $%[1]d)", filter.Model)
+	}
+	if filter.PolicyDecision != "" {
+		add("policy_decision = $%d", filter.PolicyDecision)
+	}
+	if filter.From != nil {
+		add("created_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		add("created_at < $%d", *filter.To)
+	}
+	where := ""
+	if len(conds) > 0 {
+		where = "WHERE " + strings.Join(conds, " AND ")
+	}
+	limit := filter.Limit
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT id, created_at, request_id, method, path, status_code, latency_ms,
+			COALESCE(api_key_id::text, ''), role_id, project_id, client_ip, model, served_model,
+			policy_decision, policy_code, tools_allowed, tools_removed, params_clamped,
+			cache_status, routing_strategy
+		FROM data_plane_audit_events
+		%s
+		ORDER BY created_at DESC
+		LIMIT %d
+	`, where, limit), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []domain.DataPlaneAuditEvent
+	for rows.Next() {
+		var e domain.DataPlaneAuditEvent
+		var allowed, removed, clamped pq.StringArray
+		if err := rows.Scan(&e.ID, &e.CreatedAt, &e.RequestID, &e.Method, &e.Path, &e.StatusCode, &e.LatencyMs,
+			&e.APIKeyID, &e.RoleID, &e.ProjectID, &e.ClientIP, &e.Model, &e.ServedModel,
+			&e.PolicyDecision, &e.PolicyCode, &allowed, &removed, &clamped,
+			&e.CacheStatus, &e.RoutingStrategy); err != nil {
+			return nil, err
+		}
+		e.ToolsAllowed, e.ToolsRemoved, e.ParamsClamped = allowed, removed, clamped
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// EnsureDataPlaneAuditPartition creates the partition for the month of t
+func (s *TenantStore) EnsureDataPlaneAuditPartition(ctx context.Context, t time.Time) error {
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s PARTITION OF data_plane_audit_events
+		FOR VALUES FROM ('%s') TO ('%s')
+	`, dataPlaneAuditPartition(start), start.Format(time.RFC3339), start.AddDate(0, 1, 0).Format(time.RFC3339)))
+	return err
+}
+
+// DropDataPlaneAuditPartitions drops the partitions of months that ended
+// before cutoff and returns their names
+func (s *TenantStore) DropDataPlaneAuditPartitions(ctx context.Context, cutoff time.Time) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT c.relname
+		FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		JOIN pg_class p ON p.oid = i.inhparent
+		WHERE p.relname = 'data_plane_audit_events'
+	`)
+	if err != nil {
+		return nil, err
+	}
+	var expired []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		var year, month int
+		if _, err := fmt.Sscanf(name, "data_plane_audit_events_y%04dm%02d", &year, &month); err != nil {
+			continue // Not one of ours
+		}
+		if end := time.Date(year, time.Month(month)+1, 1, 0, 0, 0, 0, time.UTC); !end.After(cutoff) {
+			expired = append(expired, name)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i, name := range expired {
+		if _, err := s.db.ExecContext(ctx, "DROP TABLE IF EXISTS "+pq.QuoteIdentifier(name)); err != nil {
+			return expired[:i], err
+		}
+	}
+	return expired, nil
+}
+
+// nonNilStrings returns list, or an empty list for nil so array columns get '{}'
+func nonNilStrings(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
+}
@@ -8,6 +8,7 @@ import (
 	"io/ioutil"
 	"log"
 	"path/filepath"
+	"sort"
 	"time"
 
 	_ "github.com/lib/pq"
@@ -17,7 +18,8 @@ import (
 // DB wraps a sql.DB with helper methods
 type DB struct {
 	*sql.DB
-	config *config.DatabaseConfig
+	config   *config.DatabaseConfig
+	replicas *ReplicaSet
 }
 
 // NewDB creates a new database connection
@@ -44,8 +46,11 @@ func NewDB(cfg *config.DatabaseConfig, dsn string) (*DB, error) {
 	return &DB{DB: db, config: cfg}, nil
 }
 
-// Close closes the database connection
+// Close closes the database connection and any read replicas
 func (db *DB) Close() error {
+	if db.replicas != nil {
+		db.replicas.Close()
+	}
 	return db.DB.Close()
 }
 
@@ -59,6 +64,20 @@ func (db *DB) Config() *config.DatabaseConfig {
 	return db.config
 }
 
+// Replicas returns the read replicas analytics queries are routed to, or
+// nil when none are configured
+func (db *DB) Replicas() *ReplicaSet {
+	return db.replicas
+}
+
+// reader returns the querier for read-only analytics queries
+func (db *DB) reader() Querier {
+	if db.replicas != nil {
+		return db.replicas.reader()
+	}
+	return db
+}
+
 // CreateDatabase creates a new database
 func CreateDatabase(cfg *config.DatabaseConfig, dbName string) error {
 	// Connect to postgres database to create new database
@@ -154,6 +173,23 @@ func RunSchemaFromFile(db *sql.DB, schemaPath string) error {
 	return nil
 }
 
+// RunMigrations runs every *.sql file in dir in lexical order.
+// Files are tracked in schema_migrations, so already-applied files are skipped.
+func RunMigrations(db *sql.DB, dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.sql"))
+	if err != nil {
+		return fmt.Errorf("failed to list migrations in %s: %w", dir, err)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		if err := RunSchemaFromFile(db, file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // InitDB initializes the database with schema
 func InitDB(cfg *config.DatabaseConfig) (*DB, error) {
 	// Create the database if it doesn't exist
@@ -167,13 +203,21 @@ func InitDB(cfg *config.DatabaseConfig) (*DB, error) {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	// Run schema from migrations folder
-	schemaPath := "migrations/001_schema.sql"
-	if err := RunSchemaFromFile(db.DB, schemaPath); err != nil {
+	// Run schema and incremental migrations from migrations folder
+	if err := RunMigrations(db.DB, "migrations"); err != nil {
 		// Try to continue even if schema application fails (might already exist)
 		log.Printf("Warning: Schema application issue: %v", err)
 	}
 
+	if len(cfg.Replicas.DSNs) > 0 {
+		if db.replicas, err = NewReplicaSet(db, cfg); err != nil {
+			db.Close()
+			return nil, err
+		}
+		db.replicas.Start()
+		log.Printf("Routing analytics queries to %d read replica(s)", len(cfg.Replicas.DSNs))
+	}
+
 	log.Println("Database initialized successfully")
 	return db, nil
 }
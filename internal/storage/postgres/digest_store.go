@@ -0,0 +1,163 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+// ============================================================================
+// Usage Digests
+// ============================================================================
+
+// ListDigestSubscriptions returns the active users subscribed to a digest
+func (s *TenantStore) ListDigestSubscriptions(ctx context.Context) ([]*domain.DigestSubscription, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, email, COALESCE(name, ''), digest_frequency, digest_role_id, digest_last_sent_at
+		FROM users
+		WHERE digest_frequency <> 'off' AND is_active = true
+		ORDER BY email
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subs []*domain.DigestSubscription
+	for rows.Next() {
+		sub, err := scanDigestSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+	return subs, rows.Err()
+}
+
+// GetDigestSubscription returns a user's digest preference, or nil if the
+// user does not exist
+func (s *TenantStore) GetDigestSubscription(ctx context.Context, userID string) (*domain.DigestSubscription, error) {
+	sub, err := scanDigestSubscription(s.db.QueryRowContext(ctx, `
+		SELECT id, email, COALESCE(name, ''), digest_frequency, digest_role_id, digest_last_sent_at
+		FROM users WHERE id = $1
+	`, userID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return sub, err
+}
+
+func scanDigestSubscription(row interface{ Scan(...any) error }) (*domain.DigestSubscription, error) {
+	var sub domain.DigestSubscription
+	var roleID sql.NullString
+	if err := row.Scan(&sub.UserID, &sub.Email, &sub.Name, &sub.Frequency, &roleID, &sub.LastSentAt); err != nil {
+		return nil, err
+	}
+	sub.RoleID = roleID.String
+	return &sub, nil
+}
+
+// SetDigestSubscription saves a user's digest preference. lastSentAt is where
+// the schedule restarts, so a new subscription waits for the next send time.
+func (s *TenantStore) SetDigestSubscription(ctx context.Context, userID string, frequency domain.DigestFrequency, roleID string, lastSentAt *time.Time) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE users
+		SET digest_frequency = $2, digest_role_id = $3, digest_last_sent_at = $4, updated_at = NOW()
+		WHERE id = $1
+	`, userID, frequency, nullString(roleID), lastSentAt)
+	return err
+}
+
+// ClaimDigest marks the digest scheduled at scheduled as sent to the user,
+// returning false if it already was (e.g. by another instance)
+func (s *TenantStore) ClaimDigest(ctx context.Context, userID string, scheduled time.Time) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE users SET digest_last_sent_at = $2
+		WHERE id = $1 AND (digest_last_sent_at IS NULL OR digest_last_sent_at < $2)
+	`, userID, scheduled)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ReleaseDigest undoes a claim whose digest could not be sent, so it is retried
+func (s *TenantStore) ReleaseDigest(ctx context.Context, userID string, previous *time.Time) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE users SET digest_last_sent_at = $2 WHERE id = $1`, userID, previous)
+	return err
+}
+
+// GetDigestUsage returns usage totals between start and end, with the top
+// models and API keys by cost (top = 0 skips them). A roleID limits usage to
+// the API keys of that role.
+func (s *TenantStore) GetDigestUsage(ctx context.Context, start, end time.Time, roleID string, top int) (*domain.UsageDigest, error) {
+	const scope = `
+		ur.created_at >= $1 AND ur.created_at < $2 AND ur.deleted_at IS NULL
+			AND ($3::uuid IS NULL OR ur.api_key_id IN (SELECT id FROM api_keys WHERE role_id = $3::uuid))
+	`
+	role := nullString(roleID)
+	digest := &domain.UsageDigest{PeriodStart: start, PeriodEnd: end, RoleID: roleID}
+
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*),
+			COUNT(*) FILTER (WHERE NOT ur.is_success),
+			COALESCE(SUM(ur.total_tokens), 0),
+			COALESCE(SUM(ur.cost_usd), 0)
+		FROM usage_records ur
+		WHERE `+scope, start, end, role).Scan(
+		&digest.TotalRequests, &digest.FailedRequests, &digest.TotalTokens, &digest.TotalCostUSD)
+	if err != nil || top <= 0 {
+		return digest, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT ur.model, COUNT(*),
+			COALESCE(SUM(ur.input_tokens), 0), COALESCE(SUM(ur.output_tokens), 0),
+			COALESCE(SUM(ur.cost_usd), 0) AS cost_usd
+		FROM usage_records ur
+		WHERE `+scope+`
+		GROUP BY ur.model
+		ORDER BY cost_usd DESC, COUNT(*) DESC
+		LIMIT $4
+	`, start, end, role, top)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var m domain.ModelUsage
+		if err := rows.Scan(&m.ModelID, &m.Requests, &m.InputTokens, &m.OutputTokens, &m.CostUSD); err != nil {
+			return nil, err
+		}
+		digest.TopModels = append(digest.TopModels, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	keyRows, err := s.db.QueryContext(ctx, `
+		SELECT ur.api_key_id, COALESCE(ak.name, ''), COUNT(*),
+			COALESCE(SUM(ur.total_tokens), 0), COALESCE(SUM(ur.cost_usd), 0) AS cost_usd
+		FROM usage_records ur
+		LEFT JOIN api_keys ak ON ur.api_key_id = ak.id
+		WHERE ur.api_key_id IS NOT NULL AND `+scope+`
+		GROUP BY ur.api_key_id, ak.name
+		ORDER BY cost_usd DESC, COUNT(*) DESC
+		LIMIT $4
+	`, start, end, role, top)
+	if err != nil {
+		return nil, err
+	}
+	defer keyRows.Close()
+	for keyRows.Next() {
+		var k domain.APIKeyUsageStats
+		if err := keyRows.Scan(&k.APIKeyID, &k.APIKeyName, &k.Requests, &k.TotalTokens, &k.CostUSD); err != nil {
+			return nil, err
+		}
+		digest.TopAPIKeys = append(digest.TopAPIKeys, k)
+	}
+	return digest, keyRows.Err()
+}
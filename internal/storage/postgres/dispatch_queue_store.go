@@ -0,0 +1,179 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+// ============================================================================
+// Durable Dispatcher Queue
+// ============================================================================
+
+const queuedRequestColumns = `id, idempotency_key, api_key_id, role_id, group_id, priority, request,
+	status, response, COALESCE(error, ''), enqueued_at, started_at, completed_at`
+
+func scanQueuedRequest(row interface{ Scan(...any) error }) (*domain.QueuedRequest, error) {
+	var q domain.QueuedRequest
+	var request, response []byte
+	var startedAt, completedAt sql.NullTime
+	if err := row.Scan(&q.ID, &q.IdempotencyKey, &q.APIKeyID, &q.RoleID, &q.GroupID, &q.Priority, &request,
+		&q.Status, &response, &q.Error, &q.EnqueuedAt, &startedAt, &completedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(request, &q.Request); err != nil {
+		return nil, fmt.Errorf("decode queued request %s: %w", q.ID, err)
+	}
+	if len(response) > 0 {
+		if err := json.Unmarshal(response, &q.Response); err != nil {
+			return nil, fmt.Errorf("decode response of queued request %s: %w", q.ID, err)
+		}
+	}
+	if startedAt.Valid {
+		q.StartedAt = &startedAt.Time
+	}
+	if completedAt.Valid {
+		q.CompletedAt = &completedAt.Time
+	}
+	return &q, nil
+}
+
+// EnqueueRequest stores a request as queued, filling in its ID. If the API key
+// already used the idempotency key, the existing request is returned instead
+// and nothing is stored, unless that request failed; a failed request is
+// queued again with the new body.
+func (s *TenantStore) EnqueueRequest(ctx context.Context, q *domain.QueuedRequest) (*domain.QueuedRequest, error) {
+	request, err := json.Marshal(q.Request)
+	if err != nil {
+		return nil, err
+	}
+	err = s.db.QueryRowContext(ctx, `
+		INSERT INTO dispatch_queue (idempotency_key, api_key_id, role_id, group_id, priority, request)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (api_key_id, idempotency_key) DO UPDATE
+		SET role_id = EXCLUDED.role_id,
+		    group_id = EXCLUDED.group_id,
+		    priority = EXCLUDED.priority,
+		    request = EXCLUDED.request,
+		    status = 'queued',
+		    response = NULL,
+		    error = NULL,
+		    enqueued_at = NOW(),
+		    started_at = NULL,
+		    completed_at = NULL
+		WHERE dispatch_queue.status = 'failed'
+		RETURNING id, status, enqueued_at
+	`, q.IdempotencyKey, q.APIKeyID, q.RoleID, q.GroupID, q.Priority, request).Scan(&q.ID, &q.Status, &q.EnqueuedAt)
+	if err == nil {
+		return nil, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+	return scanQueuedRequest(s.db.QueryRowContext(ctx, `
+		SELECT `+queuedRequestColumns+`
+		FROM dispatch_queue
+		WHERE api_key_id = $1 AND idempotency_key = $2
+	`, q.APIKeyID, q.IdempotencyKey))
+}
+
+// GetQueuedRequest returns a queued request, or nil if it does not exist
+func (s *TenantStore) GetQueuedRequest(ctx context.Context, id string) (*domain.QueuedRequest, error) {
+	q, err := scanQueuedRequest(s.db.QueryRowContext(ctx, `
+		SELECT `+queuedRequestColumns+`
+		FROM dispatch_queue
+		WHERE id = $1
+	`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return q, err
+}
+
+// ClaimQueuedRequest moves a queued request to processing. Returns false if it
+// was already claimed or finished.
+func (s *TenantStore) ClaimQueuedRequest(ctx context.Context, id string) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE dispatch_queue SET status = 'processing', started_at = NOW()
+		WHERE id = $1 AND status = 'queued'
+	`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// CompleteQueuedRequest records the outcome of a request: completed with resp,
+// or failed with errMsg when errMsg is set
+func (s *TenantStore) CompleteQueuedRequest(ctx context.Context, id string, resp *domain.ChatResponse, errMsg string) error {
+	status := domain.QueuedRequestCompleted
+	var response []byte
+	if errMsg != "" {
+		status = domain.QueuedRequestFailed
+	} else {
+		var err error
+		if response, err = json.Marshal(resp); err != nil {
+			return err
+		}
+	}
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE dispatch_queue
+		SET status = $2, response = $3, error = $4, completed_at = NOW()
+		WHERE id = $1
+	`, id, status, response, nullString(errMsg))
+	return err
+}
+
+// FailStaleQueuedRequests fails requests that have been processing since
+// before staleBefore, whose worker is presumed gone
+func (s *TenantStore) FailStaleQueuedRequests(ctx context.Context, staleBefore time.Time) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE dispatch_queue
+		SET status = 'failed', error = 'interrupted by a restart', completed_at = NOW()
+		WHERE status = 'processing' AND started_at < $1
+	`, staleBefore)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// ListQueuedRequests returns the requests waiting to be processed, highest
+// priority and oldest first
+func (s *TenantStore) ListQueuedRequests(ctx context.Context) ([]*domain.QueuedRequest, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+queuedRequestColumns+`
+		FROM dispatch_queue
+		WHERE status = 'queued'
+		ORDER BY priority DESC, enqueued_at
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var requests []*domain.QueuedRequest
+	for rows.Next() {
+		q, err := scanQueuedRequest(rows)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, q)
+	}
+	return requests, rows.Err()
+}
+
+// DeleteFinishedQueuedRequests deletes completed and failed requests that
+// finished before the cutoff, returning how many were deleted
+func (s *TenantStore) DeleteFinishedQueuedRequests(ctx context.Context, before time.Time) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM dispatch_queue WHERE completed_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
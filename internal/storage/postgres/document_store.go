@@ -0,0 +1,236 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"modelgate/internal/domain"
+
+	"github.com/lib/pq"
+	"github.com/pgvector/pgvector-go"
+)
+
+// ============================================================================
+// Documents
+// ============================================================================
+
+const storedDocumentColumns = `id, collection, filename, mime_type, bytes, status, COALESCE(error, ''),
+	pages, ocr_pages, chunks, COALESCE(api_key_id, ''), created_at, updated_at`
+
+func scanStoredDocument(row interface{ Scan(...any) error }) (*domain.StoredDocument, error) {
+	var d domain.StoredDocument
+	err := row.Scan(&d.ID, &d.Collection, &d.Filename, &d.MimeType, &d.Bytes, &d.Status, &d.Error,
+		&d.Pages, &d.OCRPages, &d.Chunks, &d.APIKeyID, &d.CreatedAt, &d.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// CreateStoredDocument records a document being ingested and fills in its ID
+// and timestamps
+func (s *TenantStore) CreateStoredDocument(ctx context.Context, d *domain.StoredDocument) error {
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO documents (collection, filename, mime_type, bytes, status, api_key_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at
+	`, d.Collection, d.Filename, d.MimeType, d.Bytes, string(d.Status), nullString(d.APIKeyID)).Scan(&d.ID, &d.CreatedAt, &d.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("create document: %w", err)
+	}
+	return nil
+}
+
+// UpdateStoredDocument saves a document's processing state
+func (s *TenantStore) UpdateStoredDocument(ctx context.Context, d *domain.StoredDocument) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE documents SET status = $2, error = $3, pages = $4, ocr_pages = $5, chunks = $6, updated_at = NOW()
+		WHERE id = $1
+	`, d.ID, string(d.Status), nullString(d.Error), d.Pages, d.OCRPages, d.Chunks)
+	return err
+}
+
+// GetStoredDocument returns a document, or nil if there is none
+func (s *TenantStore) GetStoredDocument(ctx context.Context, id string) (*domain.StoredDocument, error) {
+	d, err := scanStoredDocument(s.db.QueryRowContext(ctx, `SELECT `+storedDocumentColumns+` FROM documents WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return d, err
+}
+
+// ListStoredDocuments returns the newest documents visible to an API key
+// (its own and admin uploads; every document when apiKeyID is empty),
+// optionally of one collection
+func (s *TenantStore) ListStoredDocuments(ctx context.Context, apiKeyID, collection string, limit int) ([]*domain.StoredDocument, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+storedDocumentColumns+`
+		FROM documents
+		WHERE ($1 = '' OR api_key_id IS NULL OR api_key_id = $1)
+		  AND ($2 = '' OR collection = $2)
+		ORDER BY created_at DESC
+		LIMIT $3
+	`, apiKeyID, collection, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var docs []*domain.StoredDocument
+	for rows.Next() {
+		d, err := scanStoredDocument(rows)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, d)
+	}
+	return docs, rows.Err()
+}
+
+// DeleteStoredDocument removes a document and its chunks. Returns false when
+// it doesn't exist.
+func (s *TenantStore) DeleteStoredDocument(ctx context.Context, id string) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM documents WHERE id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("delete document: %w", err)
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// SaveDocumentChunks replaces a document's chunks. Chunks without an
+// embedding are stored unembedded, for the next reindex.
+func (s *TenantStore) SaveDocumentChunks(ctx context.Context, documentID string, chunks []domain.DocumentChunk, embeddings [][]float32) error {
+	for _, e := range embeddings {
+		if e != nil {
+			if err := s.fitDocumentChunkVectors(ctx, len(e)); err != nil {
+				return err
+			}
+			break
+		}
+	}
+
+	tx, err := s.beginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM document_chunks WHERE document_id = $1`, documentID); err != nil {
+		return fmt.Errorf("clear document chunks: %w", err)
+	}
+	for i, c := range chunks {
+		var embedding any
+		if i < len(embeddings) && embeddings[i] != nil {
+			embedding = pgvector.NewVector(embeddings[i])
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO document_chunks (document_id, chunk_index, page, text, embedding)
+			VALUES ($1, $2, $3, $4, $5)
+		`, documentID, c.Index, c.Page, c.Text, embedding); err != nil {
+			return fmt.Errorf("save document chunk: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// fitDocumentChunkVectors resizes the chunk embedding column to dims while no
+// chunk is embedded, as after an embedder reset. With embedded chunks of
+// another size it refuses, because they couldn't be compared.
+func (s *TenantStore) fitDocumentChunkVectors(ctx context.Context, dims int) error {
+	var colType string
+	var embedded bool
+	err := s.db.QueryRowContext(ctx, `
+		SELECT format_type(atttypid, atttypmod),
+			EXISTS (SELECT 1 FROM document_chunks WHERE embedding IS NOT NULL)
+		FROM pg_attribute
+		WHERE attrelid = 'document_chunks'::regclass
+		  AND attname = 'embedding'
+		  AND NOT attisdropped
+	`).Scan(&colType, &embedded)
+	if err != nil {
+		return err
+	}
+	m := vectorTypeDims.FindStringSubmatch(colType)
+	if m == nil {
+		return nil
+	}
+	if current, _ := strconv.Atoi(m[1]); current == dims {
+		return nil
+	}
+	if embedded {
+		return fmt.Errorf("embedding has %d dimensions, the stored chunks %s", dims, m[1])
+	}
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf(`ALTER TABLE document_chunks ALTER COLUMN embedding TYPE vector(%d)`, dims)); err != nil {
+		return fmt.Errorf("resize document chunk embeddings: %w", err)
+	}
+	return nil
+}
+
+// ListUnembeddedDocumentChunks returns up to limit chunks without an embedding
+func (s *TenantStore) ListUnembeddedDocumentChunks(ctx context.Context, limit int) ([]*domain.DocumentChunk, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, document_id, chunk_index, page, text
+		FROM document_chunks
+		WHERE embedding IS NULL
+		ORDER BY document_id, chunk_index
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var chunks []*domain.DocumentChunk
+	for rows.Next() {
+		var c domain.DocumentChunk
+		if err := rows.Scan(&c.ID, &c.DocumentID, &c.Index, &c.Page, &c.Text); err != nil {
+			return nil, err
+		}
+		chunks = append(chunks, &c)
+	}
+	return chunks, rows.Err()
+}
+
+// SetDocumentChunkEmbedding stores a chunk's embedding
+func (s *TenantStore) SetDocumentChunkEmbedding(ctx context.Context, id string, embedding []float32) error {
+	if err := s.fitDocumentChunkVectors(ctx, len(embedding)); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx, `UPDATE document_chunks SET embedding = $2 WHERE id = $1`, id, pgvector.NewVector(embedding))
+	return err
+}
+
+// SearchDocumentChunks returns the chunks of ready documents most similar to
+// an embedding, most similar first
+func (s *TenantStore) SearchDocumentChunks(ctx context.Context, embedding []float32, search domain.DocumentSearch) ([]*domain.DocumentChunkMatch, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT c.id, c.document_id, c.chunk_index, c.page, c.text, d.filename, d.collection, 1 - (c.embedding <=> $1)
+		FROM document_chunks c
+		JOIN documents d ON d.id = c.document_id
+		WHERE c.embedding IS NOT NULL
+		  AND d.status = 'ready'
+		  AND (d.id::text = ANY($2) OR d.collection = ANY($3))
+		  AND ($4 = '' OR d.api_key_id IS NULL OR d.api_key_id = $4)
+		ORDER BY c.embedding <=> $1
+		LIMIT $5
+	`, pgvector.NewVector(embedding), pq.Array(nonNilStrings(search.DocumentIDs)), pq.Array(nonNilStrings(search.Collections)), search.APIKeyID, search.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search document chunks: %w", err)
+	}
+	defer rows.Close()
+
+	var matches []*domain.DocumentChunkMatch
+	for rows.Next() {
+		var m domain.DocumentChunkMatch
+		if err := rows.Scan(&m.Chunk.ID, &m.Chunk.DocumentID, &m.Chunk.Index, &m.Chunk.Page, &m.Chunk.Text,
+			&m.Filename, &m.Collection, &m.Score); err != nil {
+			return nil, err
+		}
+		matches = append(matches, &m)
+	}
+	return matches, rows.Err()
+}
@@ -0,0 +1,131 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"modelgate/internal/domain"
+)
+
+// ============================================================================
+// Embedder settings per feature
+// ============================================================================
+
+// ListEmbedderSettings returns the stored embedder of each feature that has one
+func (s *TenantStore) ListEmbedderSettings(ctx context.Context) ([]*domain.EmbedderSettings, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT feature, type, model, base_url, api_key, dimensions, COALESCE(updated_by, ''), updated_at
+		FROM embedder_settings
+		ORDER BY feature
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var settings []*domain.EmbedderSettings
+	for rows.Next() {
+		var e domain.EmbedderSettings
+		if err := rows.Scan(&e.Feature, &e.Type, &e.Model, &e.BaseURL, &e.APIKey, &e.Dimensions, &e.UpdatedBy, &e.UpdatedAt); err != nil {
+			return nil, err
+		}
+		settings = append(settings, &e)
+	}
+	return settings, rows.Err()
+}
+
+// SaveEmbedderSettings sets a feature's embedder
+func (s *TenantStore) SaveEmbedderSettings(ctx context.Context, e *domain.EmbedderSettings) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO embedder_settings (feature, type, model, base_url, api_key, dimensions, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (feature) DO UPDATE SET
+			type = EXCLUDED.type,
+			model = EXCLUDED.model,
+			base_url = EXCLUDED.base_url,
+			api_key = EXCLUDED.api_key,
+			dimensions = EXCLUDED.dimensions,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = EXCLUDED.updated_at
+	`, e.Feature, e.Type, e.Model, e.BaseURL, e.APIKey, e.Dimensions, nullString(e.UpdatedBy), e.UpdatedAt)
+	return err
+}
+
+// DeleteEmbedderSettings returns a feature to the config.toml embedder and
+// reports whether it had its own
+func (s *TenantStore) DeleteEmbedderSettings(ctx context.Context, feature domain.EmbedderFeature) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM embedder_settings WHERE feature = $1`, feature)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ResetEmbeddings clears the vectors a feature stored with its previous
+// embedder and, when dimensions is set, resizes its pgvector column to match
+// the new one. Semantic cache entries keep their exact-match hash; MCP tools
+// and the injection corpus need to be embedded again.
+func (s *TenantStore) ResetEmbeddings(ctx context.Context, feature domain.EmbedderFeature, dimensions int) error {
+	tx, err := s.beginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	switch feature {
+	case domain.EmbedderFeatureSemanticCache:
+		if _, err := tx.ExecContext(ctx, `UPDATE semantic_cache SET embedding = NULL WHERE embedding IS NOT NULL`); err != nil {
+			return fmt.Errorf("clear semantic cache embeddings: %w", err)
+		}
+		if dimensions > 0 {
+			if _, err := tx.ExecContext(ctx, fmt.Sprintf(`ALTER TABLE semantic_cache ALTER COLUMN embedding TYPE vector(%d)`, dimensions)); err != nil {
+				return fmt.Errorf("resize semantic cache embeddings: %w", err)
+			}
+		}
+	case domain.EmbedderFeatureToolSearch:
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE mcp_tools SET name_embedding = NULL, description_embedding = NULL, combined_embedding = NULL
+			WHERE combined_embedding IS NOT NULL
+		`); err != nil {
+			return fmt.Errorf("clear tool embeddings: %w", err)
+		}
+		if dims := s.toolVectorDimensions(ctx); dims > 0 {
+			if _, err := tx.ExecContext(ctx, `UPDATE mcp_tools SET combined_embedding_vector = NULL`); err != nil {
+				return fmt.Errorf("clear tool vectors: %w", err)
+			}
+			if dimensions > 0 && dimensions != dims {
+				if _, err := tx.ExecContext(ctx, fmt.Sprintf(`ALTER TABLE mcp_tools ALTER COLUMN combined_embedding_vector TYPE vector(%d)`, dimensions)); err != nil {
+					return fmt.Errorf("resize tool vectors: %w", err)
+				}
+			}
+		}
+	case domain.EmbedderFeatureInjection:
+		if _, err := tx.ExecContext(ctx, `UPDATE injection_patterns SET embedding = NULL WHERE embedding IS NOT NULL`); err != nil {
+			return fmt.Errorf("clear injection pattern embeddings: %w", err)
+		}
+		if dimensions > 0 {
+			if _, err := tx.ExecContext(ctx, fmt.Sprintf(`ALTER TABLE injection_patterns ALTER COLUMN embedding TYPE vector(%d)`, dimensions)); err != nil {
+				return fmt.Errorf("resize injection pattern embeddings: %w", err)
+			}
+		}
+	case domain.EmbedderFeatureDocuments:
+		if _, err := tx.ExecContext(ctx, `UPDATE document_chunks SET embedding = NULL WHERE embedding IS NOT NULL`); err != nil {
+			return fmt.Errorf("clear document chunk embeddings: %w", err)
+		}
+		if dimensions > 0 {
+			if _, err := tx.ExecContext(ctx, fmt.Sprintf(`ALTER TABLE document_chunks ALTER COLUMN embedding TYPE vector(%d)`, dimensions)); err != nil {
+				return fmt.Errorf("resize document chunk embeddings: %w", err)
+			}
+		}
+	default:
+		return fmt.Errorf("unknown embedder feature %q", feature)
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	if feature == domain.EmbedderFeatureToolSearch && dimensions > 0 && s.toolVectorDimensions(ctx) > 0 {
+		s.shared.toolVectorDims.Store(int32(dimensions))
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"modelgate/internal/domain"
+
+	"github.com/lib/pq"
+)
+
+// ============================================================================
+// Event streaming outbox
+// ============================================================================
+
+// outboxTables maps each event type to the table whose inserts it streams
+var outboxTables = map[domain.StreamEventType]string{
+	domain.StreamEventUsage:           "usage_records",
+	domain.StreamEventPolicyViolation: "policy_violation_events",
+	domain.StreamEventAudit:           "audit_logs",
+}
+
+// SetOutboxTriggers makes inserts of the given event types enqueue outbox
+// events, and stops the other types from doing so
+func (s *TenantStore) SetOutboxTriggers(ctx context.Context, types []domain.StreamEventType) error {
+	enabled := make(map[domain.StreamEventType]bool, len(types))
+	for _, t := range types {
+		enabled[t] = true
+	}
+
+	tx, err := s.beginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for t, table := range outboxTables {
+		trigger := pq.QuoteIdentifier(table + "_outbox")
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("DROP TRIGGER IF EXISTS %s ON %s", trigger, table)); err != nil {
+			return err
+		}
+		if !enabled[t] {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(
+			"CREATE TRIGGER %s AFTER INSERT ON %s FOR EACH ROW EXECUTE FUNCTION enqueue_outbox_event(%s)",
+			trigger, table, pq.QuoteLiteral(string(t)),
+		)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// PublishOutboxEvents locks up to limit events due for publishing, oldest
+// first, and passes them to publish. They are marked published if it
+// succeeds; otherwise they are retried with exponential backoff. Rows locked
+// by another instance are skipped. It returns the number of events handled.
+func (s *TenantStore) PublishOutboxEvents(ctx context.Context, limit int, publish func([]domain.OutboxEvent) error) (int, error) {
+	tx, err := s.beginTx(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, event_type, payload, created_at, attempts
+		FROM event_outbox
+		WHERE published_at IS NULL AND next_attempt_at <= NOW()
+		ORDER BY id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	`, limit)
+	if err != nil {
+		return 0, err
+	}
+	var events []domain.OutboxEvent
+	var ids []int64
+	for rows.Next() {
+		var e domain.OutboxEvent
+		if err := rows.Scan(&e.ID, &e.Type, &e.Payload, &e.CreatedAt, &e.Attempts); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		events = append(events, e)
+		ids = append(ids, e.ID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	if publishErr := publish(events); publishErr != nil {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE event_outbox SET
+				attempts = attempts + 1,
+				last_error = $2,
+				next_attempt_at = NOW() + make_interval(secs => LEAST(POWER(2, attempts), 300))
+			WHERE id = ANY($1)
+		`, pq.Array(ids), publishErr.Error())
+	} else {
+		_, err = tx.ExecContext(ctx, `UPDATE event_outbox SET published_at = NOW() WHERE id = ANY($1)`, pq.Array(ids))
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(events), tx.Commit()
+}
+
+// DeletePublishedOutboxEvents deletes events published before cutoff
+func (s *TenantStore) DeletePublishedOutboxEvents(ctx context.Context, cutoff time.Time) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM event_outbox WHERE published_at < $1`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,167 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"modelgate/internal/domain"
+
+	"github.com/lib/pq"
+)
+
+// ============================================================================
+// Experiments
+// ============================================================================
+
+const experimentColumns = `id, name, description, status, model, role_ids, api_key_ids, variants,
+	COALESCE(created_by, ''), created_at, updated_at, started_at, ended_at`
+
+func scanExperiment(row interface{ Scan(...any) error }) (*domain.Experiment, error) {
+	var e domain.Experiment
+	var variants []byte
+	var startedAt, endedAt sql.NullTime
+	if err := row.Scan(&e.ID, &e.Name, &e.Description, &e.Status, &e.Model, pq.Array(&e.RoleIDs), pq.Array(&e.APIKeyIDs),
+		&variants, &e.CreatedBy, &e.CreatedAt, &e.UpdatedAt, &startedAt, &endedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(variants, &e.Variants); err != nil {
+		return nil, fmt.Errorf("decode variants of experiment %s: %w", e.ID, err)
+	}
+	if startedAt.Valid {
+		e.StartedAt = &startedAt.Time
+	}
+	if endedAt.Valid {
+		e.EndedAt = &endedAt.Time
+	}
+	return &e, nil
+}
+
+// ListExperiments returns every experiment, newest first
+func (s *TenantStore) ListExperiments(ctx context.Context) ([]*domain.Experiment, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+experimentColumns+`
+		FROM experiments
+		ORDER BY created_at DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var experiments []*domain.Experiment
+	for rows.Next() {
+		e, err := scanExperiment(rows)
+		if err != nil {
+			return nil, err
+		}
+		experiments = append(experiments, e)
+	}
+	return experiments, rows.Err()
+}
+
+// GetExperiment returns an experiment, or nil if it does not exist
+func (s *TenantStore) GetExperiment(ctx context.Context, id string) (*domain.Experiment, error) {
+	e, err := scanExperiment(s.db.QueryRowContext(ctx, `
+		SELECT `+experimentColumns+`
+		FROM experiments
+		WHERE id = $1
+	`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return e, err
+}
+
+// CreateExperiment inserts an experiment, filling in its ID and timestamps
+func (s *TenantStore) CreateExperiment(ctx context.Context, e *domain.Experiment) error {
+	variants, err := json.Marshal(e.Variants)
+	if err != nil {
+		return err
+	}
+	return s.db.QueryRowContext(ctx, `
+		INSERT INTO experiments (name, description, status, model, role_ids, api_key_ids, variants, created_by, started_at, ended_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, created_at, updated_at
+	`, e.Name, e.Description, e.Status, e.Model, pq.Array(nonNilStrings(e.RoleIDs)), pq.Array(nonNilStrings(e.APIKeyIDs)),
+		variants, nullString(e.CreatedBy), e.StartedAt, e.EndedAt).Scan(&e.ID, &e.CreatedAt, &e.UpdatedAt)
+}
+
+// UpdateExperiment saves an experiment's definition and status
+func (s *TenantStore) UpdateExperiment(ctx context.Context, e *domain.Experiment) error {
+	variants, err := json.Marshal(e.Variants)
+	if err != nil {
+		return err
+	}
+	err = s.db.QueryRowContext(ctx, `
+		UPDATE experiments SET name = $2, description = $3, status = $4, model = $5, role_ids = $6,
+			api_key_ids = $7, variants = $8, started_at = $9, ended_at = $10, updated_at = NOW()
+		WHERE id = $1
+		RETURNING updated_at
+	`, e.ID, e.Name, e.Description, e.Status, e.Model, pq.Array(nonNilStrings(e.RoleIDs)), pq.Array(nonNilStrings(e.APIKeyIDs)),
+		variants, e.StartedAt, e.EndedAt).Scan(&e.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("experiment not found: %s", e.ID)
+	}
+	return err
+}
+
+// DeleteExperiment deletes an experiment and reports whether it existed. Its
+// usage records keep the experiment ID.
+func (s *TenantStore) DeleteExperiment(ctx context.Context, id string) (bool, error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM experiments WHERE id = $1`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// GetExperimentVariantStats aggregates an experiment's usage records, and the
+// feedback on them, per variant. Stream timing only covers streamed requests.
+func (s *TenantStore) GetExperimentVariantStats(ctx context.Context, experimentID string, startTime, endTime time.Time) ([]domain.ExperimentVariantStats, error) {
+	rows, err := s.analytics().QueryContext(ctx, `
+		SELECT
+			ur.experiment_variant,
+			COUNT(*) as requests,
+			COUNT(*) FILTER (WHERE ur.is_success) as successful,
+			COALESCE(AVG(ur.latency_ms), 0),
+			COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY ur.latency_ms), 0),
+			COALESCE(percentile_cont(0.95) WITHIN GROUP (ORDER BY ur.latency_ms), 0),
+			COALESCE(AVG(ur.time_to_first_token_ms), 0),
+			COALESCE(AVG(ur.input_tokens), 0),
+			COALESCE(AVG(ur.output_tokens), 0),
+			COALESCE(SUM(ur.cost_usd), 0),
+			COUNT(f.id),
+			COUNT(*) FILTER (WHERE f.thumbs_up),
+			COUNT(*) FILTER (WHERE NOT f.thumbs_up),
+			COALESCE(AVG(f.rating), 0)
+		FROM usage_records ur
+		LEFT JOIN response_feedback f ON f.usage_record_id = ur.id
+		WHERE ur.experiment_id = $1 AND ur.created_at >= $2 AND ur.created_at <= $3 AND ur.deleted_at IS NULL
+		GROUP BY ur.experiment_variant
+	`, experimentID, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []domain.ExperimentVariantStats
+	for rows.Next() {
+		var v domain.ExperimentVariantStats
+		var successful int64
+		if err := rows.Scan(&v.Variant, &v.Requests, &successful, &v.AvgLatencyMs, &v.P50LatencyMs, &v.P95LatencyMs,
+			&v.AvgTTFTMs, &v.AvgInputTokens, &v.AvgOutputTokens, &v.TotalCostUSD,
+			&v.Feedback, &v.ThumbsUp, &v.ThumbsDown, &v.AvgRating); err != nil {
+			return nil, err
+		}
+		if v.Requests > 0 {
+			v.SuccessRate = float64(successful) / float64(v.Requests)
+			v.CostPerRequest = v.TotalCostUSD / float64(v.Requests)
+		}
+		stats = append(stats, v)
+	}
+	return stats, rows.Err()
+}
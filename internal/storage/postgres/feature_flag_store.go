@@ -0,0 +1,165 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"modelgate/internal/domain"
+)
+
+// ============================================================================
+// Feature flags and their changelog
+// ============================================================================
+
+// ListFeatureFlags returns the stored state of every flag that has been set, keyed by flag key
+func (s *TenantStore) ListFeatureFlags(ctx context.Context) (map[string]*domain.FeatureFlag, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT key, enabled, role_overrides, updated_by, updated_at
+		FROM feature_flags
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	flags := make(map[string]*domain.FeatureFlag)
+	for rows.Next() {
+		var f domain.FeatureFlag
+		var overridesJSON []byte
+		var updatedBy sql.NullString
+		var updatedAt sql.NullTime
+		if err := rows.Scan(&f.Key, &f.Enabled, &overridesJSON, &updatedBy, &updatedAt); err != nil {
+			return nil, err
+		}
+		json.Unmarshal(overridesJSON, &f.RoleOverrides)
+		f.UpdatedBy = updatedBy.String
+		if updatedAt.Valid {
+			f.UpdatedAt = &updatedAt.Time
+		}
+		flags[f.Key] = &f
+	}
+	return flags, rows.Err()
+}
+
+// SaveFeatureFlag stores the state of a flag and appends the change to the changelog in one transaction
+func (s *TenantStore) SaveFeatureFlag(ctx context.Context, flag *domain.FeatureFlag, change *domain.FeatureFlagChange) error {
+	overridesJSON, err := json.Marshal(flag.RoleOverrides)
+	if err != nil {
+		return fmt.Errorf("encode role overrides: %w", err)
+	}
+	if flag.RoleOverrides == nil {
+		overridesJSON = []byte("{}")
+	}
+
+	tx, err := s.beginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO feature_flags (key, enabled, role_overrides, updated_by)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (key) DO UPDATE SET
+			enabled = EXCLUDED.enabled,
+			role_overrides = EXCLUDED.role_overrides,
+			updated_by = EXCLUDED.updated_by
+	`, flag.Key, flag.Enabled, overridesJSON, sql.NullString{String: flag.UpdatedBy, Valid: flag.UpdatedBy != ""})
+	if err != nil {
+		return err
+	}
+
+	var enabled, previous sql.NullBool
+	if change.Enabled != nil {
+		enabled = sql.NullBool{Bool: *change.Enabled, Valid: true}
+	}
+	if change.PreviousEnabled != nil {
+		previous = sql.NullBool{Bool: *change.PreviousEnabled, Valid: true}
+	}
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO feature_flag_changes (flag_key, role_id, enabled, previous_enabled, note, actor_id, actor_email)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`, change.FlagKey,
+		sql.NullString{String: change.RoleID, Valid: change.RoleID != ""},
+		enabled, previous,
+		sql.NullString{String: change.Note, Valid: change.Note != ""},
+		sql.NullString{String: change.ActorID, Valid: change.ActorID != ""},
+		sql.NullString{String: change.ActorEmail, Valid: change.ActorEmail != ""},
+	).Scan(&change.ID, &change.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ListFeatureFlagChanges returns changelog entries, newest first
+func (s *TenantStore) ListFeatureFlagChanges(ctx context.Context, filter domain.FeatureFlagChangeFilter) ([]*domain.FeatureFlagChange, error) {
+	query := `
+		SELECT c.id, c.flag_key, c.role_id, r.name, c.enabled, c.previous_enabled,
+			c.note, c.actor_id, c.actor_email, c.created_at
+		FROM feature_flag_changes c
+		LEFT JOIN roles r ON c.role_id = r.id
+		WHERE 1=1
+	`
+	args := []interface{}{}
+	argIndex := 1
+
+	if filter.FlagKey != "" {
+		query += fmt.Sprintf(" AND c.flag_key = $%d", argIndex)
+		args = append(args, filter.FlagKey)
+		argIndex++
+	}
+	if filter.StartTime != nil {
+		query += fmt.Sprintf(" AND c.created_at >= $%d", argIndex)
+		args = append(args, *filter.StartTime)
+		argIndex++
+	}
+	if filter.EndTime != nil {
+		query += fmt.Sprintf(" AND c.created_at <= $%d", argIndex)
+		args = append(args, *filter.EndTime)
+		argIndex++
+	}
+
+	query += " ORDER BY c.created_at DESC"
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	query += fmt.Sprintf(" LIMIT $%d", argIndex)
+	args = append(args, limit)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []*domain.FeatureFlagChange
+	for rows.Next() {
+		var c domain.FeatureFlagChange
+		var roleID, roleName, note, actorID, actorEmail sql.NullString
+		var enabled, previous sql.NullBool
+		if err := rows.Scan(&c.ID, &c.FlagKey, &roleID, &roleName, &enabled, &previous,
+			&note, &actorID, &actorEmail, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		c.RoleID = roleID.String
+		c.RoleName = roleName.String
+		c.Note = note.String
+		c.ActorID = actorID.String
+		c.ActorEmail = actorEmail.String
+		if enabled.Valid {
+			c.Enabled = &enabled.Bool
+		}
+		if previous.Valid {
+			c.PreviousEnabled = &previous.Bool
+		}
+		changes = append(changes, &c)
+	}
+	return changes, rows.Err()
+}
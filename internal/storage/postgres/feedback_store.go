@@ -0,0 +1,167 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+// ============================================================================
+// Response feedback
+// ============================================================================
+
+const feedbackColumns = `f.id, f.request_id, f.usage_record_id, f.model, f.provider, COALESCE(f.role_id::text, ''),
+	COALESCE(f.api_key_id::text, ''), f.thumbs_up, f.rating, f.comment, COALESCE(f.submitted_by, ''),
+	f.created_at, f.updated_at`
+
+func scanFeedback(row interface{ Scan(...any) error }) (*domain.Feedback, error) {
+	var f domain.Feedback
+	var thumbsUp sql.NullBool
+	var rating sql.NullInt32
+	if err := row.Scan(&f.ID, &f.RequestID, &f.UsageRecordID, &f.Model, &f.Provider, &f.RoleID, &f.APIKeyID,
+		&thumbsUp, &rating, &f.Comment, &f.SubmittedBy, &f.CreatedAt, &f.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if thumbsUp.Valid {
+		f.ThumbsUp = &thumbsUp.Bool
+	}
+	if rating.Valid {
+		r := int(rating.Int32)
+		f.Rating = &r
+	}
+	return &f, nil
+}
+
+// SaveFeedback stores feedback on the response to f.RequestID, replacing any
+// earlier feedback on it, and fills in what it learned from the usage record.
+// With apiKeyID, only responses to that key are found. It returns false when
+// there is no such response.
+func (s *TenantStore) SaveFeedback(ctx context.Context, f *domain.Feedback, apiKeyID string) (bool, error) {
+	var thumbsUp sql.NullBool
+	if f.ThumbsUp != nil {
+		thumbsUp = sql.NullBool{Bool: *f.ThumbsUp, Valid: true}
+	}
+	var rating sql.NullInt32
+	if f.Rating != nil {
+		rating = sql.NullInt32{Int32: int32(*f.Rating), Valid: true}
+	}
+
+	// A personal key's role is its owner's
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO response_feedback (request_id, usage_record_id, model, provider, role_id, api_key_id,
+			thumbs_up, rating, comment, submitted_by)
+		SELECT ur.request_id, ur.id, ur.model, ur.provider, COALESCE(ak.role_id, u.api_role_id), ur.api_key_id,
+			$3, $4, $5, $6
+		FROM usage_records ur
+		LEFT JOIN api_keys ak ON ak.id = ur.api_key_id
+		LEFT JOIN users u ON u.id = ak.owner_user_id
+		WHERE ur.request_id = $1 AND ur.deleted_at IS NULL AND ($2::uuid IS NULL OR ur.api_key_id = $2::uuid)
+		ORDER BY ur.created_at DESC
+		LIMIT 1
+		ON CONFLICT (request_id) DO UPDATE SET
+			thumbs_up = EXCLUDED.thumbs_up,
+			rating = EXCLUDED.rating,
+			comment = EXCLUDED.comment,
+			submitted_by = EXCLUDED.submitted_by,
+			updated_at = NOW()
+		RETURNING id, usage_record_id, model, provider, COALESCE(role_id::text, ''), COALESCE(api_key_id::text, ''),
+			created_at, updated_at
+	`, f.RequestID, nullString(apiKeyID), thumbsUp, rating, f.Comment, nullString(f.SubmittedBy)).Scan(
+		&f.ID, &f.UsageRecordID, &f.Model, &f.Provider, &f.RoleID, &f.APIKeyID, &f.CreatedAt, &f.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// ListFeedback returns feedback matching a filter, newest first
+func (s *TenantStore) ListFeedback(ctx context.Context, filter domain.FeedbackFilter) ([]*domain.Feedback, error) {
+	query := `
+		SELECT ` + feedbackColumns + `
+		FROM response_feedback f
+		WHERE TRUE
+	`
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	if !filter.From.IsZero() {
+		query += " AND f.created_at >= " + arg(filter.From)
+	}
+	if !filter.To.IsZero() {
+		query += " AND f.created_at <= " + arg(filter.To)
+	}
+	if filter.Model != "" {
+		query += " AND f.model = " + arg(filter.Model)
+	}
+	if filter.RoleID != "" {
+		query += " AND f.role_id = " + arg(filter.RoleID) + "::uuid"
+	}
+	if filter.ThumbsUp != nil {
+		query += " AND f.thumbs_up = " + arg(*filter.ThumbsUp)
+	}
+	query += " ORDER BY f.created_at DESC"
+	if filter.Limit > 0 {
+		query += " LIMIT " + arg(filter.Limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*domain.Feedback
+	for rows.Next() {
+		f, err := scanFeedback(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, f)
+	}
+	return list, rows.Err()
+}
+
+// GetFeedbackStats sums up feedback given between startTime and endTime per
+// model, or per role with byRole, optionally for one project's requests
+func (s *TenantStore) GetFeedbackStats(ctx context.Context, startTime, endTime time.Time, byRole bool, projectID string) ([]*domain.FeedbackStats, error) {
+	key, name := "f.model", "f.model"
+	if byRole {
+		key, name = "COALESCE(f.role_id::text, '')", "COALESCE(r.name, '')"
+	}
+	rows, err := s.analytics().QueryContext(ctx, `
+		SELECT `+key+`, `+name+`,
+			COUNT(*),
+			COUNT(*) FILTER (WHERE f.thumbs_up),
+			COUNT(*) FILTER (WHERE NOT f.thumbs_up),
+			COUNT(f.rating),
+			COALESCE(AVG(f.rating), 0),
+			COUNT(*) FILTER (WHERE f.comment <> '')
+		FROM response_feedback f
+		LEFT JOIN roles r ON r.id = f.role_id
+		LEFT JOIN usage_records ur ON ur.id = f.usage_record_id
+		WHERE f.created_at >= $1 AND f.created_at <= $2
+			AND ($3::uuid IS NULL OR ur.project_id = $3::uuid)
+		GROUP BY 1, 2
+		ORDER BY 3 DESC
+	`, startTime, endTime, nullString(projectID))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []*domain.FeedbackStats
+	for rows.Next() {
+		var st domain.FeedbackStats
+		if err := rows.Scan(&st.Key, &st.Name, &st.Count, &st.ThumbsUp, &st.ThumbsDown, &st.Rated,
+			&st.AvgRating, &st.Comments); err != nil {
+			return nil, err
+		}
+		stats = append(stats, &st)
+	}
+	return stats, rows.Err()
+}
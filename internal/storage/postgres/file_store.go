@@ -0,0 +1,297 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+// ============================================================================
+// Uploads (resumable multipart uploads)
+// ============================================================================
+
+const uploadColumns = `id, api_key_id, filename, purpose, mime_type, bytes, sha256,
+	status, file_id, expires_at, completed_at, created_at, updated_at`
+
+// CreateUpload creates a new pending upload
+func (s *TenantStore) CreateUpload(ctx context.Context, upload *domain.Upload) error {
+	now := time.Now()
+	if upload.Status == "" {
+		upload.Status = domain.UploadStatusPending
+	}
+	upload.CreatedAt = now
+	upload.UpdatedAt = now
+
+	query := `
+		INSERT INTO uploads (api_key_id, filename, purpose, mime_type, bytes, sha256, status, expires_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id
+	`
+	return s.db.QueryRowContext(ctx, query,
+		nullString(upload.APIKeyID), upload.Filename, upload.Purpose, nullString(upload.MimeType),
+		upload.Bytes, nullString(upload.SHA256), upload.Status, upload.ExpiresAt, now, now,
+	).Scan(&upload.ID)
+}
+
+// GetUpload gets an upload by ID
+func (s *TenantStore) GetUpload(ctx context.Context, id string) (*domain.Upload, error) {
+	query := `SELECT ` + uploadColumns + ` FROM uploads WHERE id = $1`
+	upload, err := scanUpload(s.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return upload, err
+}
+
+// UpdateUploadStatus sets the status of an upload that is still pending.
+// Returns false if the upload was no longer pending.
+func (s *TenantStore) UpdateUploadStatus(ctx context.Context, id string, status domain.UploadStatus) (bool, error) {
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE uploads SET status = $2 WHERE id = $1 AND status = 'pending'`, id, status)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// ListExpiredUploads lists pending uploads whose expiry has passed
+func (s *TenantStore) ListExpiredUploads(ctx context.Context, now time.Time, limit int) ([]*domain.Upload, error) {
+	query := `SELECT ` + uploadColumns + ` FROM uploads
+		WHERE status = 'pending' AND expires_at < $1
+		ORDER BY expires_at
+		LIMIT $2`
+	rows, err := s.db.QueryContext(ctx, query, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var uploads []*domain.Upload
+	for rows.Next() {
+		upload, err := scanUpload(rows)
+		if err != nil {
+			return nil, err
+		}
+		uploads = append(uploads, upload)
+	}
+	return uploads, rows.Err()
+}
+
+// SaveUploadPart records a received part. Re-sending a part number replaces it.
+func (s *TenantStore) SaveUploadPart(ctx context.Context, part *domain.UploadPart) error {
+	query := `
+		INSERT INTO upload_parts (upload_id, part_number, bytes, sha256, storage_path, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (upload_id, part_number) DO UPDATE SET
+			bytes = EXCLUDED.bytes,
+			sha256 = EXCLUDED.sha256,
+			storage_path = EXCLUDED.storage_path,
+			created_at = EXCLUDED.created_at
+	`
+	if part.CreatedAt.IsZero() {
+		part.CreatedAt = time.Now()
+	}
+	_, err := s.db.ExecContext(ctx, query,
+		part.UploadID, part.PartNumber, part.Bytes, part.SHA256, part.StoragePath, part.CreatedAt)
+	return err
+}
+
+// ListUploadParts lists the parts received for an upload, ordered by part number
+func (s *TenantStore) ListUploadParts(ctx context.Context, uploadID string) ([]*domain.UploadPart, error) {
+	query := `
+		SELECT upload_id, part_number, bytes, sha256, storage_path, created_at
+		FROM upload_parts WHERE upload_id = $1
+		ORDER BY part_number
+	`
+	rows, err := s.db.QueryContext(ctx, query, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var parts []*domain.UploadPart
+	for rows.Next() {
+		var part domain.UploadPart
+		if err := rows.Scan(&part.UploadID, &part.PartNumber, &part.Bytes, &part.SHA256, &part.StoragePath, &part.CreatedAt); err != nil {
+			return nil, err
+		}
+		parts = append(parts, &part)
+	}
+	return parts, rows.Err()
+}
+
+// CompleteUpload creates the stitched file and marks the upload completed in one transaction
+func (s *TenantStore) CompleteUpload(ctx context.Context, uploadID string, file *domain.File) error {
+	tx, err := s.beginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := insertFile(ctx, tx, file); err != nil {
+		return fmt.Errorf("insert file: %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE uploads SET status = 'completed', file_id = $2, completed_at = $3
+		WHERE id = $1 AND status = 'pending'
+	`, uploadID, file.ID, file.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("update upload: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("upload %s is no longer pending", uploadID)
+	}
+
+	return tx.Commit()
+}
+
+// CreateFile records a file that was written directly (not via an upload)
+func (s *TenantStore) CreateFile(ctx context.Context, file *domain.File) error {
+	return insertFile(ctx, s.db, file)
+}
+
+const fileColumns = `id, api_key_id, filename, purpose, mime_type, bytes, sha256, storage_path, status, created_at,
+	storage, provider, provider_file_id, model, expires_at`
+
+// GetFile gets a file by ID
+func (s *TenantStore) GetFile(ctx context.Context, id string) (*domain.File, error) {
+	file, err := scanFile(s.db.QueryRowContext(ctx, `SELECT `+fileColumns+` FROM files WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return file, err
+}
+
+// ListFiles lists the files that are not deleted, newest first. An empty
+// apiKeyID or purpose matches all.
+func (s *TenantStore) ListFiles(ctx context.Context, apiKeyID, purpose string, limit int) ([]*domain.File, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+fileColumns+` FROM files
+		WHERE status <> 'deleted'
+		  AND ($1 = '' OR api_key_id::text = $1)
+		  AND ($2 = '' OR purpose = $2)
+		ORDER BY created_at DESC
+		LIMIT $3
+	`, apiKeyID, purpose, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanFiles(rows)
+}
+
+// ListExpiredFiles lists files past their retention that are not yet deleted
+func (s *TenantStore) ListExpiredFiles(ctx context.Context, now time.Time, limit int) ([]*domain.File, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+fileColumns+` FROM files
+		WHERE status = 'processed' AND expires_at IS NOT NULL AND expires_at <= $1
+		ORDER BY expires_at
+		LIMIT $2
+	`, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanFiles(rows)
+}
+
+// MarkFileDeleted marks a file deleted. It returns false if the file was
+// already deleted.
+func (s *TenantStore) MarkFileDeleted(ctx context.Context, id string) (bool, error) {
+	result, err := s.db.ExecContext(ctx, `UPDATE files SET status = 'deleted' WHERE id = $1 AND status <> 'deleted'`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func scanFiles(rows *sql.Rows) ([]*domain.File, error) {
+	defer rows.Close()
+	var files []*domain.File
+	for rows.Next() {
+		file, err := scanFile(rows)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	return files, rows.Err()
+}
+
+func scanFile(row rowScanner) (*domain.File, error) {
+	var file domain.File
+	var apiKeyID, mimeType, provider, providerFileID, model sql.NullString
+	var expiresAt sql.NullTime
+	err := row.Scan(
+		&file.ID, &apiKeyID, &file.Filename, &file.Purpose, &mimeType,
+		&file.Bytes, &file.SHA256, &file.StoragePath, &file.Status, &file.CreatedAt,
+		&file.Storage, &provider, &providerFileID, &model, &expiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	file.APIKeyID = apiKeyID.String
+	file.MimeType = mimeType.String
+	file.Provider = domain.Provider(provider.String)
+	file.ProviderFileID = providerFileID.String
+	file.Model = model.String
+	if expiresAt.Valid {
+		file.ExpiresAt = &expiresAt.Time
+	}
+	return &file, nil
+}
+
+// queryRower is satisfied by *sql.DB and *sql.Tx
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func insertFile(ctx context.Context, db queryRower, file *domain.File) error {
+	if file.Status == "" {
+		file.Status = domain.FileStatusProcessed
+	}
+	if file.Storage == "" {
+		file.Storage = domain.FileStorageLocal
+	}
+	file.CreatedAt = time.Now()
+
+	return db.QueryRowContext(ctx, `
+		INSERT INTO files (api_key_id, filename, purpose, mime_type, bytes, sha256, storage_path, status, created_at,
+			storage, provider, provider_file_id, model, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		RETURNING id
+	`, nullString(file.APIKeyID), file.Filename, file.Purpose, nullString(file.MimeType),
+		file.Bytes, file.SHA256, file.StoragePath, file.Status, file.CreatedAt,
+		file.Storage, nullString(string(file.Provider)), nullString(file.ProviderFileID), nullString(file.Model), file.ExpiresAt,
+	).Scan(&file.ID)
+}
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanUpload(row rowScanner) (*domain.Upload, error) {
+	var upload domain.Upload
+	var apiKeyID, mimeType, sha, fileID sql.NullString
+	var completedAt sql.NullTime
+	err := row.Scan(
+		&upload.ID, &apiKeyID, &upload.Filename, &upload.Purpose, &mimeType, &upload.Bytes, &sha,
+		&upload.Status, &fileID, &upload.ExpiresAt, &completedAt, &upload.CreatedAt, &upload.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	upload.APIKeyID = apiKeyID.String
+	upload.MimeType = mimeType.String
+	upload.SHA256 = sha.String
+	upload.FileID = fileID.String
+	if completedAt.Valid {
+		upload.CompletedAt = &completedAt.Time
+	}
+	return &upload, nil
+}
@@ -0,0 +1,128 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+// ============================================================================
+// Fine-tuning jobs (run by providers)
+// ============================================================================
+
+const fineTuneJobColumns = `id, api_key_id, provider, model, training_file_id, validation_file_id,
+	hyperparameters, suffix, status, provider_job_id, fine_tuned_model, trained_tokens, error,
+	metadata, finished_at, created_at, updated_at`
+
+// CreateFineTuneJob records a new fine-tuning job
+func (s *TenantStore) CreateFineTuneJob(ctx context.Context, job *domain.FineTuneJob) error {
+	hyperparameters, err := json.Marshal(job.Hyperparameters)
+	if err != nil {
+		return err
+	}
+	metadata, err := json.Marshal(job.Metadata)
+	if err != nil {
+		return err
+	}
+	if job.Status == "" {
+		job.Status = domain.FineTuneStatusValidatingFiles
+	}
+	return s.db.QueryRowContext(ctx, `
+		INSERT INTO fine_tuning_jobs (
+			api_key_id, provider, model, training_file_id, validation_file_id, hyperparameters,
+			suffix, status, metadata
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at, updated_at
+	`, nullString(job.APIKeyID), string(job.Provider), job.Model, nullString(job.TrainingFileID),
+		nullString(job.ValidationFileID), hyperparameters, job.Suffix, job.Status, metadata,
+	).Scan(&job.ID, &job.CreatedAt, &job.UpdatedAt)
+}
+
+// UpdateFineTuneJob saves the state of a job as last seen at the provider
+func (s *TenantStore) UpdateFineTuneJob(ctx context.Context, job *domain.FineTuneJob) error {
+	job.UpdatedAt = time.Now()
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE fine_tuning_jobs SET
+			status = $2, provider_job_id = $3, fine_tuned_model = $4, trained_tokens = $5,
+			error = $6, finished_at = $7
+		WHERE id = $1
+	`, job.ID, job.Status, nullString(job.ProviderJobID), nullString(job.FineTunedModel),
+		job.TrainedTokens, nullString(job.Error), job.FinishedAt,
+	)
+	return err
+}
+
+// GetFineTuneJob returns a job, or nil if it does not exist
+func (s *TenantStore) GetFineTuneJob(ctx context.Context, id string) (*domain.FineTuneJob, error) {
+	job, err := scanFineTuneJob(s.db.QueryRowContext(ctx,
+		`SELECT `+fineTuneJobColumns+` FROM fine_tuning_jobs WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return job, err
+}
+
+// ListFineTuneJobs lists jobs, newest first. If apiKeyID is set, only that key's jobs are returned.
+func (s *TenantStore) ListFineTuneJobs(ctx context.Context, apiKeyID string, limit int) ([]*domain.FineTuneJob, error) {
+	return s.queryFineTuneJobs(ctx, `SELECT `+fineTuneJobColumns+` FROM fine_tuning_jobs
+		WHERE ($1 = '' OR api_key_id::text = $1)
+		ORDER BY created_at DESC
+		LIMIT $2`, apiKeyID, limit)
+}
+
+// ListActiveFineTuneJobs lists jobs started at a provider that have not finished
+func (s *TenantStore) ListActiveFineTuneJobs(ctx context.Context) ([]*domain.FineTuneJob, error) {
+	return s.queryFineTuneJobs(ctx, `SELECT `+fineTuneJobColumns+` FROM fine_tuning_jobs
+		WHERE status IN ('validating_files', 'queued', 'running') AND provider_job_id IS NOT NULL
+		ORDER BY created_at`)
+}
+
+func (s *TenantStore) queryFineTuneJobs(ctx context.Context, query string, args ...any) ([]*domain.FineTuneJob, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []*domain.FineTuneJob
+	for rows.Next() {
+		job, err := scanFineTuneJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+func scanFineTuneJob(row rowScanner) (*domain.FineTuneJob, error) {
+	var job domain.FineTuneJob
+	var apiKeyID, trainingFileID, validationFileID, providerJobID, fineTunedModel, jobError sql.NullString
+	var provider string
+	var hyperparameters, metadata []byte
+	var finishedAt sql.NullTime
+	err := row.Scan(
+		&job.ID, &apiKeyID, &provider, &job.Model, &trainingFileID, &validationFileID,
+		&hyperparameters, &job.Suffix, &job.Status, &providerJobID, &fineTunedModel, &job.TrainedTokens, &jobError,
+		&metadata, &finishedAt, &job.CreatedAt, &job.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	job.APIKeyID = apiKeyID.String
+	job.Provider = domain.Provider(provider)
+	job.TrainingFileID = trainingFileID.String
+	job.ValidationFileID = validationFileID.String
+	job.ProviderJobID = providerJobID.String
+	job.FineTunedModel = fineTunedModel.String
+	job.Error = jobError.String
+	json.Unmarshal(hyperparameters, &job.Hyperparameters)
+	json.Unmarshal(metadata, &job.Metadata)
+	if finishedAt.Valid {
+		job.FinishedAt = &finishedAt.Time
+	}
+	return &job, nil
+}
@@ -0,0 +1,44 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+// GetDailyCostByAPIKey returns each API key's cost per UTC day between start and end, by day
+func (s *TenantStore) GetDailyCostByAPIKey(ctx context.Context, start, end time.Time) ([]*domain.APIKeyDailyCost, error) {
+	query := `
+		SELECT ur.api_key_id, COALESCE(ak.name, ''), ak.role_id, ak.group_id, ak.budget,
+			date_trunc('day', ur.created_at AT TIME ZONE 'UTC') AS day,
+			COALESCE(SUM(ur.cost_usd), 0)
+		FROM usage_records ur
+		LEFT JOIN api_keys ak ON ur.api_key_id = ak.id
+		WHERE ur.created_at >= $1 AND ur.created_at < $2 AND ur.deleted_at IS NULL AND ur.api_key_id IS NOT NULL
+		GROUP BY ur.api_key_id, ak.name, ak.role_id, ak.group_id, ak.budget, day
+		ORDER BY day
+	`
+	rows, err := s.analytics().QueryContext(ctx, query, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var costs []*domain.APIKeyDailyCost
+	for rows.Next() {
+		var c domain.APIKeyDailyCost
+		var roleID, groupID sql.NullString
+		var budgetJSON []byte
+		if err := rows.Scan(&c.APIKeyID, &c.APIKeyName, &roleID, &groupID, &budgetJSON, &c.Day, &c.CostUSD); err != nil {
+			return nil, err
+		}
+		c.RoleID = roleID.String
+		c.GroupID = groupID.String
+		c.Budget = unmarshalAPIKeyBudget(budgetJSON)
+		c.Day = time.Date(c.Day.Year(), c.Day.Month(), c.Day.Day(), 0, 0, 0, 0, time.UTC)
+		costs = append(costs, &c)
+	}
+	return costs, rows.Err()
+}
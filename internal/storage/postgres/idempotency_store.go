@@ -0,0 +1,94 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+// ============================================================================
+// Idempotency keys
+// ============================================================================
+
+// ClaimIdempotencyKey records rec as in progress unless the key is held by
+// an unexpired record, which is returned instead. A record still in progress
+// past its lock is taken over, as its request died without completing.
+func (s *TenantStore) ClaimIdempotencyKey(ctx context.Context, rec *domain.IdempotencyRecord, now time.Time) (*domain.IdempotencyRecord, error) {
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO idempotency_keys (api_key_id, key, request_hash, status, locked_until, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (api_key_id, key) DO UPDATE SET
+			request_hash = EXCLUDED.request_hash,
+			status = EXCLUDED.status,
+			status_code = 0,
+			header = '{}',
+			body = NULL,
+			locked_until = EXCLUDED.locked_until,
+			expires_at = EXCLUDED.expires_at,
+			created_at = EXCLUDED.created_at
+		WHERE idempotency_keys.expires_at < $7
+		   OR (idempotency_keys.status = $4 AND idempotency_keys.locked_until < $7)
+	`, rec.APIKeyID, rec.Key, rec.RequestHash, domain.IdempotencyInProgress, rec.LockedUntil, rec.ExpiresAt, now)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := result.RowsAffected(); n > 0 {
+		return nil, nil
+	}
+
+	existing := &domain.IdempotencyRecord{APIKeyID: rec.APIKeyID, Key: rec.Key}
+	var header []byte
+	err = s.db.QueryRowContext(ctx, `
+		SELECT request_hash, status, status_code, header, body, locked_until, expires_at, created_at
+		FROM idempotency_keys WHERE api_key_id = $1 AND key = $2
+	`, rec.APIKeyID, rec.Key).Scan(&existing.RequestHash, &existing.Status, &existing.StatusCode, &header,
+		&existing.Body, &existing.LockedUntil, &existing.ExpiresAt, &existing.CreatedAt)
+	if err == sql.ErrNoRows {
+		// Released between the insert and the lookup; the caller retries later
+		existing.Status = domain.IdempotencyInProgress
+		return existing, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(header, &existing.Header); err != nil {
+		return nil, fmt.Errorf("decode idempotency headers: %w", err)
+	}
+	return existing, nil
+}
+
+// CompleteIdempotencyKey stores the response of a claimed key
+func (s *TenantStore) CompleteIdempotencyKey(ctx context.Context, rec *domain.IdempotencyRecord) error {
+	header, err := json.Marshal(rec.Header)
+	if err != nil {
+		return fmt.Errorf("encode idempotency headers: %w", err)
+	}
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE idempotency_keys
+		SET status = $3, status_code = $4, header = $5, body = $6, expires_at = $7
+		WHERE api_key_id = $1 AND key = $2 AND request_hash = $8
+	`, rec.APIKeyID, rec.Key, domain.IdempotencyCompleted, rec.StatusCode, header, rec.Body, rec.ExpiresAt, rec.RequestHash)
+	return err
+}
+
+// ReleaseIdempotencyKey deletes a key still in progress so the request can be retried
+func (s *TenantStore) ReleaseIdempotencyKey(ctx context.Context, apiKeyID, key, requestHash string) error {
+	_, err := s.db.ExecContext(ctx, `
+		DELETE FROM idempotency_keys
+		WHERE api_key_id = $1 AND key = $2 AND request_hash = $3 AND status = $4
+	`, apiKeyID, key, requestHash, domain.IdempotencyInProgress)
+	return err
+}
+
+// DeleteExpiredIdempotencyKeys deletes keys whose expiry has passed and returns how many were removed
+func (s *TenantStore) DeleteExpiredIdempotencyKeys(ctx context.Context, now time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at < $1`, now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
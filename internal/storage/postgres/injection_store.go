@@ -0,0 +1,125 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"modelgate/internal/domain"
+
+	"github.com/pgvector/pgvector-go"
+)
+
+// ============================================================================
+// Prompt injection corpus
+// ============================================================================
+
+// ListInjectionPatterns returns the injection corpus, builtin patterns first
+func (s *TenantStore) ListInjectionPatterns(ctx context.Context) ([]*domain.InjectionPattern, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, text, category, builtin, embedding IS NOT NULL, COALESCE(created_by, ''), created_at
+		FROM injection_patterns
+		ORDER BY builtin DESC, created_at, text
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var patterns []*domain.InjectionPattern
+	for rows.Next() {
+		var p domain.InjectionPattern
+		if err := rows.Scan(&p.ID, &p.Text, &p.Category, &p.Builtin, &p.Embedded, &p.CreatedBy, &p.CreatedAt); err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, &p)
+	}
+	return patterns, rows.Err()
+}
+
+// CreateInjectionPattern adds a pattern unless the corpus already has its
+// text, and reports whether it was added
+func (s *TenantStore) CreateInjectionPattern(ctx context.Context, p *domain.InjectionPattern) (bool, error) {
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO injection_patterns (text, category, builtin, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (text) DO NOTHING
+		RETURNING id
+	`, p.Text, p.Category, p.Builtin, nullString(p.CreatedBy), p.CreatedAt).Scan(&p.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// DeleteInjectionPattern removes a pattern and returns it, or nil if there was none
+func (s *TenantStore) DeleteInjectionPattern(ctx context.Context, id string) (*domain.InjectionPattern, error) {
+	var p domain.InjectionPattern
+	err := s.db.QueryRowContext(ctx, `
+		DELETE FROM injection_patterns WHERE id = $1
+		RETURNING id, text, category, builtin, embedding IS NOT NULL, COALESCE(created_by, ''), created_at
+	`, id).Scan(&p.ID, &p.Text, &p.Category, &p.Builtin, &p.Embedded, &p.CreatedBy, &p.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// SetInjectionPatternEmbedding stores a pattern's vector. While no pattern
+// has one, the vector column is resized to the embedder's dimensions, so a
+// first embedder other than the 768-dimension default works without a reset.
+func (s *TenantStore) SetInjectionPatternEmbedding(ctx context.Context, id string, embedding []float32) error {
+	var colType string
+	var embedded bool
+	err := s.db.QueryRowContext(ctx, `
+		SELECT format_type(atttypid, atttypmod),
+			EXISTS (SELECT 1 FROM injection_patterns WHERE embedding IS NOT NULL)
+		FROM pg_attribute
+		WHERE attrelid = 'injection_patterns'::regclass
+		  AND attname = 'embedding'
+		  AND NOT attisdropped
+	`).Scan(&colType, &embedded)
+	if err != nil {
+		return err
+	}
+	if m := vectorTypeDims.FindStringSubmatch(colType); m != nil {
+		if dims, _ := strconv.Atoi(m[1]); dims != len(embedding) {
+			if embedded {
+				return fmt.Errorf("embedding has %d dimensions, the corpus %d", len(embedding), dims)
+			}
+			if _, err := s.db.ExecContext(ctx, fmt.Sprintf(`ALTER TABLE injection_patterns ALTER COLUMN embedding TYPE vector(%d)`, len(embedding))); err != nil {
+				return fmt.Errorf("resize injection pattern embeddings: %w", err)
+			}
+		}
+	}
+
+	_, err = s.db.ExecContext(ctx, `UPDATE injection_patterns SET embedding = $2 WHERE id = $1`, id, pgvector.NewVector(embedding))
+	return err
+}
+
+// NearestInjectionPattern returns the embedded pattern closest to a prompt
+// embedding, or nil when none is embedded
+func (s *TenantStore) NearestInjectionPattern(ctx context.Context, embedding []float32) (*domain.InjectionMatch, error) {
+	var m domain.InjectionMatch
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, text, category, builtin, COALESCE(created_by, ''), created_at, 1 - (embedding <=> $1)
+		FROM injection_patterns
+		WHERE embedding IS NOT NULL
+		ORDER BY embedding <=> $1
+		LIMIT 1
+	`, pgvector.NewVector(embedding)).Scan(&m.Pattern.ID, &m.Pattern.Text, &m.Pattern.Category, &m.Pattern.Builtin,
+		&m.Pattern.CreatedBy, &m.Pattern.CreatedAt, &m.Similarity)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query injection patterns: %w", err)
+	}
+	m.Pattern.Embedded = true
+	return &m, nil
+}
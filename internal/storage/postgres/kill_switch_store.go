@@ -0,0 +1,76 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"modelgate/internal/domain"
+)
+
+// ============================================================================
+// Provider and model kill switches
+// ============================================================================
+
+// ListKillSwitches returns every kill switch, including expired ones not yet cleared
+func (s *TenantStore) ListKillSwitches(ctx context.Context) ([]*domain.KillSwitch, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT provider, model, reason, COALESCE(disabled_by, ''), disabled_at, expires_at
+		FROM kill_switches
+		ORDER BY disabled_at DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var switches []*domain.KillSwitch
+	for rows.Next() {
+		var ks domain.KillSwitch
+		var expiresAt sql.NullTime
+		if err := rows.Scan(&ks.Provider, &ks.Model, &ks.Reason, &ks.DisabledBy, &ks.DisabledAt, &expiresAt); err != nil {
+			return nil, err
+		}
+		if expiresAt.Valid {
+			ks.ExpiresAt = &expiresAt.Time
+		}
+		switches = append(switches, &ks)
+	}
+	return switches, rows.Err()
+}
+
+// SaveKillSwitch sets a kill switch, replacing any switch on the same target
+func (s *TenantStore) SaveKillSwitch(ctx context.Context, ks *domain.KillSwitch) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO kill_switches (provider, model, reason, disabled_by, disabled_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (provider, model) DO UPDATE SET
+			reason = EXCLUDED.reason,
+			disabled_by = EXCLUDED.disabled_by,
+			disabled_at = EXCLUDED.disabled_at,
+			expires_at = EXCLUDED.expires_at
+	`, ks.Provider, ks.Model, ks.Reason, nullString(ks.DisabledBy), ks.DisabledAt, ks.ExpiresAt)
+	return err
+}
+
+// DeleteKillSwitch clears the kill switch on a target. It returns the cleared
+// switch, or nil if there was none. With expiredOnly it only clears a switch
+// that has expired, so instances racing to expire it clear it once.
+func (s *TenantStore) DeleteKillSwitch(ctx context.Context, provider domain.Provider, model string, expiredOnly bool) (*domain.KillSwitch, error) {
+	var ks domain.KillSwitch
+	var expiresAt sql.NullTime
+	err := s.db.QueryRowContext(ctx, `
+		DELETE FROM kill_switches
+		WHERE provider = $1 AND model = $2 AND (NOT $3 OR expires_at <= NOW())
+		RETURNING provider, model, reason, COALESCE(disabled_by, ''), disabled_at, expires_at
+	`, provider, model, expiredOnly).Scan(&ks.Provider, &ks.Model, &ks.Reason, &ks.DisabledBy, &ks.DisabledAt, &expiresAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if expiresAt.Valid {
+		ks.ExpiresAt = &expiresAt.Time
+	}
+	return &ks, nil
+}
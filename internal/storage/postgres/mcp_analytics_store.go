@@ -0,0 +1,170 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"modelgate/internal/domain"
+)
+
+// topFailingToolsLimit is how many tools MCPToolAnalytics.TopFailingTools lists
+const topFailingToolsLimit = 10
+
+// mcpExecutionWhere builds the WHERE clause for an MCP tool execution filter,
+// with mcp_tool_executions aliased as e
+func mcpExecutionWhere(filter domain.MCPToolExecutionFilter) (string, []interface{}) {
+	whereClause := "WHERE 1=1"
+	var args []interface{}
+	add := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		whereClause += fmt.Sprintf(" AND "+cond, len(args))
+	}
+
+	if filter.ServerID != "" {
+		add("e.server_id = $%d", filter.ServerID)
+	}
+	if filter.ToolID != "" {
+		add("e.tool_id = $%d", filter.ToolID)
+	}
+	if filter.RoleID != "" {
+		add("e.role_id = $%d", filter.RoleID)
+	}
+	if filter.APIKeyID != "" {
+		add("e.api_key_id = $%d", filter.APIKeyID)
+	}
+	if filter.Status != "" {
+		add("e.status = $%d", string(filter.Status))
+	}
+	if !filter.StartTime.IsZero() {
+		add("e.started_at >= $%d", filter.StartTime)
+	}
+	if !filter.EndTime.IsZero() {
+		add("e.started_at <= $%d", filter.EndTime)
+	}
+	return whereClause, args
+}
+
+// mcpExecutionAggregates are the columns every MCP analytics query selects
+const mcpExecutionAggregates = `
+	COUNT(*),
+	COUNT(*) FILTER (WHERE e.status IN ('ERROR', 'TIMEOUT')),
+	COUNT(*) FILTER (WHERE e.status = 'BLOCKED'),
+	COALESCE(AVG(e.duration_ms) FILTER (WHERE e.status <> 'BLOCKED'), 0),
+	COALESCE(PERCENTILE_CONT(0.95) WITHIN GROUP (ORDER BY e.duration_ms) FILTER (WHERE e.status <> 'BLOCKED'), 0),
+	COALESCE(SUM(e.cost_usd), 0)`
+
+// GetMCPToolAnalytics aggregates the tool executions matching filter: totals,
+// breakdowns by tool, server and role, a time series bucketed by interval
+// ("hour" or "day") and the tools with the most errors
+func (s *TenantStore) GetMCPToolAnalytics(ctx context.Context, filter domain.MCPToolExecutionFilter, interval string) (*domain.MCPToolAnalytics, error) {
+	if interval != "hour" {
+		interval = "day"
+	}
+	whereClause, args := mcpExecutionWhere(filter)
+	analytics := &domain.MCPToolAnalytics{}
+
+	err := s.analytics().QueryRowContext(ctx, "SELECT "+mcpExecutionAggregates+" FROM mcp_tool_executions e "+whereClause, args...).Scan(
+		&analytics.Totals.Executions, &analytics.Totals.Errors, &analytics.Totals.Blocked,
+		&analytics.Totals.AvgDurationMs, &analytics.Totals.P95DurationMs, &analytics.Totals.CostUSD)
+	if err != nil {
+		return nil, err
+	}
+
+	groupings := []struct {
+		key, name, join string
+		into            *[]*domain.MCPToolExecutionStats
+	}{
+		{"e.tool_id", "t.name", "LEFT JOIN mcp_tools t ON t.id = e.tool_id", &analytics.ByTool},
+		{"e.server_id", "srv.name", "LEFT JOIN mcp_servers srv ON srv.id = e.server_id", &analytics.ByServer},
+		{"e.role_id", "r.name", "LEFT JOIN roles r ON r.id = e.role_id", &analytics.ByRole},
+	}
+	for _, g := range groupings {
+		stats, err := s.mcpExecutionStats(ctx, g.key, g.name, g.join, whereClause, args)
+		if err != nil {
+			return nil, err
+		}
+		*g.into = stats
+	}
+
+	analytics.TimeSeries, err = s.mcpExecutionTimeSeries(ctx, interval, whereClause, args)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tool := range analytics.ByTool {
+		if tool.Errors > 0 {
+			analytics.TopFailingTools = append(analytics.TopFailingTools, tool)
+		}
+	}
+	sort.SliceStable(analytics.TopFailingTools, func(i, j int) bool {
+		return analytics.TopFailingTools[i].Errors > analytics.TopFailingTools[j].Errors
+	})
+	if len(analytics.TopFailingTools) > topFailingToolsLimit {
+		analytics.TopFailingTools = analytics.TopFailingTools[:topFailingToolsLimit]
+	}
+
+	return analytics, nil
+}
+
+// mcpExecutionStats aggregates executions grouped by key, most executed first.
+// Executions without a role are grouped under an empty ID.
+func (s *TenantStore) mcpExecutionStats(ctx context.Context, key, name, join, whereClause string, args []interface{}) ([]*domain.MCPToolExecutionStats, error) {
+	query := fmt.Sprintf(`
+		SELECT COALESCE(%s::text, ''), COALESCE(MAX(%s), ''), %s
+		FROM mcp_tool_executions e
+		%s
+		%s
+		GROUP BY %s
+		ORDER BY COUNT(*) DESC
+	`, key, name, mcpExecutionAggregates, join, whereClause, key)
+
+	rows, err := s.analytics().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []*domain.MCPToolExecutionStats
+	for rows.Next() {
+		var st domain.MCPToolExecutionStats
+		err := rows.Scan(&st.ID, &st.Name, &st.Executions, &st.Errors, &st.Blocked,
+			&st.AvgDurationMs, &st.P95DurationMs, &st.CostUSD)
+		if err != nil {
+			return nil, err
+		}
+		stats = append(stats, &st)
+	}
+	return stats, rows.Err()
+}
+
+// mcpExecutionTimeSeries buckets executions by hour or day for charts
+func (s *TenantStore) mcpExecutionTimeSeries(ctx context.Context, interval, whereClause string, args []interface{}) ([]*domain.MCPToolExecutionPoint, error) {
+	query := fmt.Sprintf(`
+		SELECT
+			date_trunc('%s', e.started_at) AS time_bucket,
+			COUNT(*),
+			COUNT(*) FILTER (WHERE e.status IN ('ERROR', 'TIMEOUT')),
+			COALESCE(PERCENTILE_CONT(0.95) WITHIN GROUP (ORDER BY e.duration_ms) FILTER (WHERE e.status <> 'BLOCKED'), 0)
+		FROM mcp_tool_executions e
+		%s
+		GROUP BY time_bucket
+		ORDER BY time_bucket ASC
+	`, interval, whereClause)
+
+	rows, err := s.analytics().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var points []*domain.MCPToolExecutionPoint
+	for rows.Next() {
+		var point domain.MCPToolExecutionPoint
+		if err := rows.Scan(&point.Timestamp, &point.Executions, &point.Errors, &point.P95DurationMs); err != nil {
+			return nil, err
+		}
+		points = append(points, &point)
+	}
+	return points, rows.Err()
+}
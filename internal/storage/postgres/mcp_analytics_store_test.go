@@ -0,0 +1,192 @@
+package postgres
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"modelgate/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func TestMCPExecutionWhere(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+
+	where, args := mcpExecutionWhere(domain.MCPToolExecutionFilter{})
+	if where != "WHERE 1=1" || len(args) != 0 {
+		t.Fatalf("expected no conditions for an empty filter, got %q %v", where, args)
+	}
+
+	where, args = mcpExecutionWhere(domain.MCPToolExecutionFilter{
+		ServerID: "srv", ToolID: "tool", RoleID: "role", APIKeyID: "key",
+		Status: domain.MCPExecError, StartTime: start, EndTime: end,
+	})
+	wantWhere := "WHERE 1=1 AND e.server_id = $1 AND e.tool_id = $2 AND e.role_id = $3 AND e.api_key_id = $4" +
+		" AND e.status = $5 AND e.started_at >= $6 AND e.started_at <= $7"
+	if where != wantWhere {
+		t.Fatalf("unexpected where clause:\n got %s\nwant %s", where, wantWhere)
+	}
+	if want := []interface{}{"srv", "tool", "role", "key", "ERROR", start, end}; !reflect.DeepEqual(args, want) {
+		t.Fatalf("unexpected args: got %v want %v", args, want)
+	}
+
+	// Placeholders are numbered by the conditions present
+	where, args = mcpExecutionWhere(domain.MCPToolExecutionFilter{RoleID: "role", EndTime: end})
+	if where != "WHERE 1=1 AND e.role_id = $1 AND e.started_at <= $2" || len(args) != 2 {
+		t.Fatalf("unexpected where clause %q %v", where, args)
+	}
+}
+
+func TestMCPToolAnalytics(t *testing.T) {
+	s := testStore(t)
+	ctx := context.Background()
+
+	suffix := uuid.NewString()[:8]
+	server := &domain.MCPServer{
+		Name: "analytics-" + suffix, Slug: "analytics-" + suffix, ServerType: domain.MCPServerTypeSSE,
+		Endpoint: "http://localhost:9", AuthType: domain.MCPAuthNone, Status: domain.MCPStatusConnected,
+	}
+	if err := s.CreateMCPServer(ctx, server); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.DeleteMCPServer(ctx, server.ID) })
+
+	search := &domain.MCPTool{ServerID: server.ID, Name: "search", Description: "search"}
+	deploy := &domain.MCPTool{ServerID: server.ID, Name: "deploy", Description: "deploy"}
+	for _, tool := range []*domain.MCPTool{search, deploy} {
+		if err := s.UpsertMCPTool(ctx, tool); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reader, deployer := uuid.NewString(), uuid.NewString()
+	now := time.Now().Truncate(time.Second)
+	logExec := func(tool *domain.MCPTool, roleID string, status domain.MCPExecutionStatus, durationMs int, cost float64, age time.Duration) {
+		t.Helper()
+		if err := s.LogMCPToolExecution(ctx, &domain.MCPToolExecution{
+			ServerID: server.ID, ToolID: tool.ID, RoleID: roleID, APIKeyID: uuid.NewString(),
+			Status: status, DurationMs: durationMs, CostUSD: cost, StartedAt: now.Add(-age),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := range 3 {
+		logExec(search, reader, domain.MCPExecSuccess, 100, 0.01, time.Duration(i+1)*time.Minute)
+	}
+	logExec(search, reader, domain.MCPExecError, 300, 0, 5*time.Minute)
+	logExec(deploy, deployer, domain.MCPExecTimeout, 200, 0, 6*time.Minute)
+	logExec(deploy, deployer, domain.MCPExecBlocked, 0, 0, 7*time.Minute)
+
+	filter := domain.MCPToolExecutionFilter{ServerID: server.ID, StartTime: now.Add(-time.Hour), EndTime: now.Add(time.Minute)}
+	analytics, err := s.GetMCPToolAnalytics(ctx, filter, "hour")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	totals := analytics.Totals
+	if totals.Executions != 6 || totals.Errors != 2 || totals.Blocked != 1 {
+		t.Fatalf("unexpected totals %+v", totals)
+	}
+	// Durations average the five calls that ran; the blocked call is left out
+	if totals.AvgDurationMs != 160 || totals.CostUSD < 0.0299 || totals.CostUSD > 0.0301 {
+		t.Fatalf("unexpected duration or cost totals %+v", totals)
+	}
+
+	if len(analytics.ByTool) != 2 || analytics.ByTool[0].ID != search.ID || analytics.ByTool[0].Name != "search" ||
+		analytics.ByTool[0].Executions != 4 || analytics.ByTool[1].Name != "deploy" || analytics.ByTool[1].Blocked != 1 {
+		t.Fatalf("unexpected per-tool stats %+v", analytics.ByTool)
+	}
+	if len(analytics.ByServer) != 1 || analytics.ByServer[0].Name != server.Name || analytics.ByServer[0].Executions != 6 {
+		t.Fatalf("unexpected per-server stats %+v", analytics.ByServer)
+	}
+	if len(analytics.ByRole) != 2 || analytics.ByRole[0].ID != reader || analytics.ByRole[0].Executions != 4 {
+		t.Fatalf("unexpected per-role stats %+v", analytics.ByRole)
+	}
+	if len(analytics.TopFailingTools) != 2 {
+		t.Fatalf("expected both tools with errors to be listed, got %+v", analytics.TopFailingTools)
+	}
+
+	var seriesExecs, seriesErrors int64
+	for _, p := range analytics.TimeSeries {
+		seriesExecs += p.Executions
+		seriesErrors += p.Errors
+	}
+	if seriesExecs != 6 || seriesErrors != 2 {
+		t.Fatalf("expected the time series to cover every execution, got %d executions and %d errors", seriesExecs, seriesErrors)
+	}
+
+	// Filters narrow every aggregate
+	filter.RoleID = deployer
+	analytics, err = s.GetMCPToolAnalytics(ctx, filter, "day")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if analytics.Totals.Executions != 2 || len(analytics.ByTool) != 1 || analytics.ByTool[0].ID != deploy.ID {
+		t.Fatalf("expected only the deployer's executions, got %+v %+v", analytics.Totals, analytics.ByTool)
+	}
+
+	filter.RoleID = ""
+	filter.Status = domain.MCPExecError
+	analytics, err = s.GetMCPToolAnalytics(ctx, filter, "day")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if analytics.Totals.Executions != 1 || analytics.Totals.Errors != 1 {
+		t.Fatalf("expected only the failed execution, got %+v", analytics.Totals)
+	}
+}
+
+func TestListMCPToolExecutions(t *testing.T) {
+	s := testStore(t)
+	ctx := context.Background()
+
+	suffix := uuid.NewString()[:8]
+	server := &domain.MCPServer{
+		Name: "executions-" + suffix, Slug: "executions-" + suffix, ServerType: domain.MCPServerTypeSSE,
+		Endpoint: "http://localhost:9", AuthType: domain.MCPAuthNone, Status: domain.MCPStatusConnected,
+	}
+	if err := s.CreateMCPServer(ctx, server); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.DeleteMCPServer(ctx, server.ID) })
+	tool := &domain.MCPTool{ServerID: server.ID, Name: "lookup", Description: "lookup"}
+	if err := s.UpsertMCPTool(ctx, tool); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	for i := range 5 {
+		if err := s.LogMCPToolExecution(ctx, &domain.MCPToolExecution{
+			ServerID: server.ID, ToolID: tool.ID, RoleID: uuid.NewString(), APIKeyID: uuid.NewString(),
+			Status: domain.MCPExecSuccess, DurationMs: 10, StartedAt: now.Add(-time.Duration(i) * time.Minute),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	filter := domain.MCPToolExecutionFilter{ServerID: server.ID}
+
+	page, total, err := s.ListMCPToolExecutions(ctx, filter, 2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 5 || len(page) != 2 || page[0].ToolName != "lookup" || page[0].ServerName != server.Name {
+		t.Fatalf("unexpected page %+v (total %d)", page, total)
+	}
+	if !page[0].StartedAt.After(page[1].StartedAt) {
+		t.Fatal("expected the newest executions first")
+	}
+
+	// Out of range limits and offsets fall back to the defaults and the cap
+	for _, tc := range []struct{ limit, offset int }{{0, 0}, {-1, -1}, {maxMCPExecutionPage * 10, 0}} {
+		all, total, err := s.ListMCPToolExecutions(ctx, filter, tc.limit, tc.offset)
+		if err != nil {
+			t.Fatalf("limit %d offset %d: %v", tc.limit, tc.offset, err)
+		}
+		if total != 5 || len(all) != 5 {
+			t.Fatalf("limit %d offset %d: expected every execution, got %d of %d", tc.limit, tc.offset, len(all), total)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"modelgate/internal/domain"
+)
+
+// ============================================================================
+// MCP Tool Approvals
+// ============================================================================
+
+const mcpApprovalColumns = `a.id, a.role_id, a.server_id, a.tool_id,
+	COALESCE(r.name, ''), COALESCE(srv.name, ''), COALESCE(t.name, ''),
+	a.status, COALESCE(a.requested_by::text, ''), a.request_count, a.first_requested_at, a.last_requested_at,
+	COALESCE(a.visibility, ''), COALESCE(a.decided_by::text, ''), COALESCE(a.decided_by_email, ''),
+	a.decided_at, COALESCE(a.decision_reason, '')`
+
+const mcpApprovalFrom = ` FROM mcp_tool_approvals a
+	LEFT JOIN roles r ON r.id = a.role_id
+	LEFT JOIN mcp_servers srv ON srv.id = a.server_id
+	LEFT JOIN mcp_tools t ON t.id = a.tool_id`
+
+func scanMCPToolApproval(row interface{ Scan(...any) error }) (*domain.MCPToolApproval, error) {
+	var a domain.MCPToolApproval
+	var visibility string
+	if err := row.Scan(&a.ID, &a.RoleID, &a.ServerID, &a.ToolID,
+		&a.RoleName, &a.ServerName, &a.ToolName,
+		&a.Status, &a.RequestedBy, &a.RequestCount, &a.FirstRequestedAt, &a.LastRequestedAt,
+		&visibility, &a.DecidedBy, &a.DecidedByEmail, &a.DecidedAt, &a.DecisionReason); err != nil {
+		return nil, err
+	}
+	a.Visibility = domain.MCPToolVisibility(visibility)
+	return &a, nil
+}
+
+// RequestMCPToolApproval records a role's call to a tool it has no permission
+// for and returns the role's approval request for the tool. The first call
+// opens a pending request; later calls count against it. An approved request
+// whose permission has since been removed is reopened.
+func (s *TenantStore) RequestMCPToolApproval(ctx context.Context, roleID, serverID, toolID, apiKeyID string) (*domain.MCPToolApproval, error) {
+	if _, err := s.db.ExecContext(ctx, `
+		DELETE FROM mcp_tool_approvals WHERE role_id = $1 AND tool_id = $2 AND status = 'approved'
+	`, roleID, toolID); err != nil {
+		return nil, err
+	}
+
+	var id string
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO mcp_tool_approvals (role_id, server_id, tool_id, requested_by)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (role_id, tool_id) DO UPDATE SET
+			request_count = mcp_tool_approvals.request_count + 1,
+			last_requested_at = NOW()
+		RETURNING id
+	`, roleID, serverID, toolID, nullString(apiKeyID)).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetMCPToolApproval(ctx, id)
+}
+
+// GetMCPToolApproval returns an approval request, or nil if it does not exist
+func (s *TenantStore) GetMCPToolApproval(ctx context.Context, id string) (*domain.MCPToolApproval, error) {
+	a, err := scanMCPToolApproval(s.db.QueryRowContext(ctx,
+		`SELECT `+mcpApprovalColumns+mcpApprovalFrom+` WHERE a.id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return a, err
+}
+
+// ListMCPToolApprovals returns approval requests, oldest first. An empty
+// status returns all of them.
+func (s *TenantStore) ListMCPToolApprovals(ctx context.Context, status string) ([]*domain.MCPToolApproval, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+mcpApprovalColumns+mcpApprovalFrom+`
+		WHERE ($1 = '' OR a.status = $1)
+		ORDER BY a.first_requested_at
+	`, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var approvals []*domain.MCPToolApproval
+	for rows.Next() {
+		a, err := scanMCPToolApproval(rows)
+		if err != nil {
+			return nil, err
+		}
+		approvals = append(approvals, a)
+	}
+	return approvals, rows.Err()
+}
+
+// DecideMCPToolApproval records the decision on a pending request, returning
+// false if it was no longer pending (e.g. decided by another admin)
+func (s *TenantStore) DecideMCPToolApproval(ctx context.Context, id, status string, visibility domain.MCPToolVisibility, decidedBy, decidedByEmail, reason string) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE mcp_tool_approvals
+		SET status = $2, visibility = $3, decided_by = $4, decided_by_email = $5, decided_at = NOW(), decision_reason = $6
+		WHERE id = $1 AND status = 'pending'
+	`, id, status, nullString(string(visibility)), nullString(decidedBy), nullString(decidedByEmail), nullString(reason))
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// SettleMCPToolApproval closes a role's pending request for a tool after an
+// admin set the tool's permission directly
+func (s *TenantStore) SettleMCPToolApproval(ctx context.Context, perm *domain.MCPToolPermission) error {
+	status := domain.MCPApprovalApproved
+	if perm.Visibility == domain.MCPVisibilityDeny {
+		status = domain.MCPApprovalDenied
+	}
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE mcp_tool_approvals
+		SET status = $3, visibility = $4, decided_by = $5, decided_by_email = $6, decided_at = NOW(), decision_reason = $7
+		WHERE role_id = $1 AND tool_id = $2 AND status = 'pending'
+	`, perm.RoleID, perm.ToolID, status, string(perm.Visibility), nullString(perm.DecidedBy),
+		nullString(perm.DecidedByEmail), nullString(perm.DecisionReason))
+	return err
+}
@@ -0,0 +1,136 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"modelgate/internal/crypto"
+	"modelgate/internal/domain"
+)
+
+// SetEncryption sets the keyring used to encrypt MCP server auth configs,
+// attestation signing keys and the tenant data key for captured content.
+// Without one, they are stored as is.
+func (s *TenantStore) SetEncryption(keyring *crypto.Keyring) {
+	s.encryption = keyring
+}
+
+// encodeAuthConfig serializes an auth config for the auth_config_encrypted
+// column, sealed in an envelope when encryption is configured
+func (s *TenantStore) encodeAuthConfig(config domain.MCPAuthConfig) ([]byte, error) {
+	plain, err := marshalAuthConfigForStorage(config) // Don't use json.Marshal to avoid masking
+	if err != nil {
+		return nil, err
+	}
+	if s.encryption == nil {
+		return plain, nil
+	}
+	env, err := s.encryption.Seal(plain)
+	if err != nil {
+		return nil, fmt.Errorf("encrypt auth config: %w", err)
+	}
+	return json.Marshal(env)
+}
+
+// decodeAuthConfig reads an auth_config_encrypted value, which is either an
+// envelope or a plain JSON config written before encryption was enabled
+func (s *TenantStore) decodeAuthConfig(data []byte, config *domain.MCPAuthConfig) error {
+	env, ok := parseAuthEnvelope(data)
+	if !ok {
+		_ = json.Unmarshal(data, config)
+		return nil
+	}
+	if s.encryption == nil {
+		return fmt.Errorf("auth config is encrypted but no encryption key is configured")
+	}
+	plain, err := s.encryption.Open(env)
+	if err != nil {
+		return fmt.Errorf("decrypt auth config: %w", err)
+	}
+	return json.Unmarshal(plain, config)
+}
+
+// parseAuthEnvelope reports whether a stored auth config is an encrypted envelope
+func parseAuthEnvelope(data []byte) (*crypto.Envelope, bool) {
+	var env crypto.Envelope
+	if err := json.Unmarshal(data, &env); err != nil || env.KeyID == "" || env.Ciphertext == "" {
+		return nil, false
+	}
+	return &env, true
+}
+
+// ReencryptMCPAuthConfigs brings every stored auth config up to the current
+// primary key: plain JSON configs are encrypted and envelopes sealed with a
+// previous key have their data key re-wrapped. It returns the number of
+// servers updated. Rows changed concurrently are left for the next run.
+func (s *TenantStore) ReencryptMCPAuthConfigs(ctx context.Context) (int, error) {
+	if s.encryption == nil {
+		return 0, nil
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT id, auth_config_encrypted FROM mcp_servers WHERE auth_config_encrypted IS NOT NULL`)
+	if err != nil {
+		return 0, err
+	}
+	type storedConfig struct {
+		id   string
+		data []byte
+	}
+	var stored []storedConfig
+	for rows.Next() {
+		var c storedConfig
+		if err := rows.Scan(&c.id, &c.data); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		stored = append(stored, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	updated := 0
+	for _, c := range stored {
+		var next *crypto.Envelope
+		if env, ok := parseAuthEnvelope(c.data); ok {
+			changed, err := s.encryption.Rewrap(env)
+			if err != nil {
+				return updated, fmt.Errorf("MCP server %s: %w", c.id, err)
+			}
+			if !changed {
+				continue
+			}
+			next = env
+		} else {
+			var config domain.MCPAuthConfig
+			if err := json.Unmarshal(c.data, &config); err != nil {
+				return updated, fmt.Errorf("MCP server %s: invalid auth config: %w", c.id, err)
+			}
+			plain, err := marshalAuthConfigForStorage(config)
+			if err != nil {
+				return updated, err
+			}
+			if next, err = s.encryption.Seal(plain); err != nil {
+				return updated, fmt.Errorf("MCP server %s: encrypt auth config: %w", c.id, err)
+			}
+		}
+
+		data, err := json.Marshal(next)
+		if err != nil {
+			return updated, err
+		}
+		res, err := s.db.ExecContext(ctx, `
+			UPDATE mcp_servers SET auth_config_encrypted = $2
+			WHERE id = $1 AND auth_config_encrypted = $3::jsonb
+		`, c.id, data, c.data)
+		if err != nil {
+			return updated, fmt.Errorf("MCP server %s: %w", c.id, err)
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			updated++
+		}
+	}
+	return updated, nil
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"math"
 	"sort"
 	"strconv"
@@ -51,7 +52,10 @@ func (s *TenantStore) CreateMCPServer(ctx context.Context, server *domain.MCPSer
 
 	arguments, _ := json.Marshal(server.Arguments)
 	environment, _ := json.Marshal(server.Environment)
-	authConfig, _ := marshalAuthConfigForStorage(server.AuthConfig) // Don't use json.Marshal to avoid masking
+	authConfig, err := s.encodeAuthConfig(server.AuthConfig)
+	if err != nil {
+		return err
+	}
 	metadata, _ := json.Marshal(server.Metadata)
 
 	query := `
@@ -71,10 +75,10 @@ func (s *TenantStore) CreateMCPServer(ctx context.Context, server *domain.MCPSer
 		createdBy = server.CreatedBy
 	}
 
-	_, err := s.db.ExecContext(ctx, query,
+	_, err = s.db.ExecContext(ctx, query,
 		server.ID, server.Name, server.Slug, server.Description,
 		server.ServerType, server.Endpoint, arguments, environment,
-		server.AuthType, authConfig, // TODO: Encrypt auth config
+		server.AuthType, authConfig,
 		server.Version, server.CommitHash,
 		server.Status, server.AutoSync, server.SyncIntervalMinutes, server.HealthCheckIntervalSeconds,
 		pq.Array(server.Tags), metadata, createdBy,
@@ -87,7 +91,10 @@ func (s *TenantStore) CreateMCPServer(ctx context.Context, server *domain.MCPSer
 func (s *TenantStore) UpdateMCPServer(ctx context.Context, server *domain.MCPServer) error {
 	arguments, _ := json.Marshal(server.Arguments)
 	environment, _ := json.Marshal(server.Environment)
-	authConfig, _ := marshalAuthConfigForStorage(server.AuthConfig) // Don't use json.Marshal to avoid masking
+	authConfig, err := s.encodeAuthConfig(server.AuthConfig)
+	if err != nil {
+		return err
+	}
 	metadata, _ := json.Marshal(server.Metadata)
 
 	query := `
@@ -102,7 +109,7 @@ func (s *TenantStore) UpdateMCPServer(ctx context.Context, server *domain.MCPSer
 		WHERE id = $1
 	`
 
-	_, err := s.db.ExecContext(ctx, query,
+	_, err = s.db.ExecContext(ctx, query,
 		server.ID, server.Name, server.Slug, server.Description,
 		server.ServerType, server.Endpoint, arguments, environment,
 		server.AuthType, authConfig,
@@ -216,7 +223,9 @@ func (s *TenantStore) scanMCPServer(row *sql.Row) (*domain.MCPServer, error) {
 
 	_ = json.Unmarshal(arguments, &server.Arguments)
 	_ = json.Unmarshal(environment, &server.Environment)
-	_ = json.Unmarshal(authConfig, &server.AuthConfig)
+	if err := s.decodeAuthConfig(authConfig, &server.AuthConfig); err != nil {
+		return nil, fmt.Errorf("MCP server %s: %w", server.ID, err)
+	}
 	_ = json.Unmarshal(metadata, &server.Metadata)
 
 	server.Tags = tags
@@ -262,7 +271,9 @@ func (s *TenantStore) scanMCPServerFromRows(rows *sql.Rows) (*domain.MCPServer,
 
 	_ = json.Unmarshal(arguments, &server.Arguments)
 	_ = json.Unmarshal(environment, &server.Environment)
-	_ = json.Unmarshal(authConfig, &server.AuthConfig)
+	if err := s.decodeAuthConfig(authConfig, &server.AuthConfig); err != nil {
+		return nil, fmt.Errorf("MCP server %s: %w", server.ID, err)
+	}
 	_ = json.Unmarshal(metadata, &server.Metadata)
 
 	server.Tags = tags
@@ -424,8 +435,11 @@ func (s *TenantStore) UpdateToolEmbeddings(ctx context.Context, toolID string, n
 		vectorToString(descEmb),
 		vectorToString(combinedEmb),
 	)
+	if err != nil {
+		return err
+	}
 
-	return err
+	return s.updateToolVector(ctx, toolID, combinedEmb)
 }
 
 // DeprecateMCPTool marks a tool as deprecated
@@ -819,6 +833,36 @@ func (s *TenantStore) GetMCPToolVisibility(ctx context.Context, roleID, toolID s
 	return perm.Visibility
 }
 
+// ListMCPToolVisibilities returns, for each tool with a permission in any of
+// the roles, the most permissive one (ALLOW, then SEARCH, then DENY). Tools
+// missing from the map are denied.
+func (s *TenantStore) ListMCPToolVisibilities(ctx context.Context, roleIDs []string) (map[string]*domain.MCPToolPermission, error) {
+	query := `
+		SELECT DISTINCT ON (tool_id) id, role_id, server_id, tool_id,
+			visibility, decided_by, decided_by_email, decided_at, decision_reason,
+			created_at, updated_at
+		FROM mcp_tool_permissions
+		WHERE role_id = ANY($1)
+		ORDER BY tool_id, CASE visibility WHEN 'ALLOW' THEN 0 WHEN 'SEARCH' THEN 1 ELSE 2 END
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, pq.Array(roleIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	perms := make(map[string]*domain.MCPToolPermission)
+	for rows.Next() {
+		p, err := s.scanMCPToolPermissionFromRows(rows)
+		if err != nil {
+			return nil, err
+		}
+		perms[p.ToolID] = p
+	}
+	return perms, rows.Err()
+}
+
 // SetMCPToolPermission sets or updates a tool visibility permission
 func (s *TenantStore) SetMCPToolPermission(ctx context.Context, perm *domain.MCPToolPermission) error {
 	if perm.ID == "" {
@@ -885,7 +929,8 @@ func (s *TenantStore) ListMCPPermissions(ctx context.Context, roleID string) ([]
 	return perms, rows.Err()
 }
 
-// BulkSetMCPVisibility sets visibility for all tools in a server
+// BulkSetMCPVisibility sets visibility for all tools in a server. The
+// permissions are set in one transaction, so either every tool changes or none does.
 func (s *TenantStore) BulkSetMCPVisibility(ctx context.Context, roleID, serverID string, visibility domain.MCPToolVisibility, actorID, actorEmail string) (int, error) {
 	now := time.Now()
 
@@ -895,23 +940,27 @@ func (s *TenantStore) BulkSetMCPVisibility(ctx context.Context, roleID, serverID
 		return 0, err
 	}
 
-	count := 0
-	for _, tool := range tools {
-		perm := &domain.MCPToolPermission{
-			RoleID:         roleID,
-			ServerID:       serverID,
-			ToolID:         tool.ID,
-			Visibility:     visibility,
-			DecidedBy:      actorID,
-			DecidedByEmail: actorEmail,
-			DecidedAt:      &now,
-		}
-		if err := s.SetMCPToolPermission(ctx, perm); err == nil {
-			count++
+	err = s.InTx(ctx, func(tx *TenantStore) error {
+		for _, tool := range tools {
+			perm := &domain.MCPToolPermission{
+				RoleID:         roleID,
+				ServerID:       serverID,
+				ToolID:         tool.ID,
+				Visibility:     visibility,
+				DecidedBy:      actorID,
+				DecidedByEmail: actorEmail,
+				DecidedAt:      &now,
+			}
+			if err := tx.SetMCPToolPermission(ctx, perm); err != nil {
+				return fmt.Errorf("set visibility of tool %s: %w", tool.Name, err)
+			}
 		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
 	}
-
-	return count, nil
+	return len(tools), nil
 }
 
 func (s *TenantStore) scanMCPToolPermission(row *sql.Row) (*domain.MCPToolPermission, error) {
@@ -1018,142 +1067,27 @@ func (s *TenantStore) GetMCPToolsWithVisibility(ctx context.Context, serverID, r
 // ============================================
 
 // SearchToolsByVector performs semantic search using embeddings
-// Uses in-memory cosine similarity computation (works without pgvector)
 func (s *TenantStore) SearchToolsByVector(ctx context.Context, queryEmbedding []float32, limit int) ([]*domain.MCPTool, error) {
-	if len(queryEmbedding) == 0 {
-		return nil, fmt.Errorf("embedding is empty")
-	}
-
-	// Fetch all tools with embeddings
-	query := `
-		SELECT t.id, t.server_id, s.name as server_name,
-			t.name, t.description, t.category,
-			t.input_schema, t.output_schema, t.input_examples,
-			t.defer_loading, t.is_deprecated, t.deprecation_message, t.deprecated_at,
-			t.version, t.execution_count, t.last_executed_at, t.avg_execution_time_ms,
-			t.created_at, t.updated_at, t.combined_embedding
-		FROM mcp_tools t
-		JOIN mcp_servers s ON t.server_id = s.id
-		WHERE t.combined_embedding IS NOT NULL
-			AND t.combined_embedding != ''
-			AND t.is_deprecated = FALSE
-	`
-
-	rows, err := s.db.QueryContext(ctx, query)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query tools: %w", err)
-	}
-	defer rows.Close()
-
-	type toolWithScore struct {
-		tool  *domain.MCPTool
-		score float64
-	}
-
-	var toolsWithScores []toolWithScore
-
-	for rows.Next() {
-		var tool domain.MCPTool
-		var serverName sql.NullString
-		var category sql.NullString
-		var inputSchemaBytes []byte
-		var outputSchemaBytes []byte
-		var inputExamplesBytes []byte
-		var deprecationMessage sql.NullString
-		var deprecatedAt sql.NullTime
-		var version sql.NullString
-		var executionCount sql.NullInt64
-		var lastExecutedAt sql.NullTime
-		var avgExecutionTimeMs sql.NullInt64
-		var combinedEmbeddingStr sql.NullString
-
-		err := rows.Scan(
-			&tool.ID, &tool.ServerID, &serverName,
-			&tool.Name, &tool.Description, &category,
-			&inputSchemaBytes, &outputSchemaBytes, &inputExamplesBytes,
-			&tool.DeferLoading, &tool.IsDeprecated, &deprecationMessage, &deprecatedAt,
-			&version, &executionCount, &lastExecutedAt, &avgExecutionTimeMs,
-			&tool.CreatedAt, &tool.UpdatedAt, &combinedEmbeddingStr,
-		)
-		if err != nil {
-			continue
-		}
-
-		if serverName.Valid {
-			tool.ServerName = serverName.String
-		}
-		if category.Valid {
-			tool.Category = category.String
-		}
-		if len(inputSchemaBytes) > 0 {
-			json.Unmarshal(inputSchemaBytes, &tool.InputSchema)
-		}
-		if len(outputSchemaBytes) > 0 {
-			json.Unmarshal(outputSchemaBytes, &tool.OutputSchema)
-		}
-		if len(inputExamplesBytes) > 0 {
-			json.Unmarshal(inputExamplesBytes, &tool.InputExamples)
-		}
-		if deprecationMessage.Valid {
-			tool.DeprecationMessage = deprecationMessage.String
-		}
-		if deprecatedAt.Valid {
-			tool.DeprecatedAt = &deprecatedAt.Time
-		}
-		if version.Valid {
-			tool.Version = version.String
-		}
-		if executionCount.Valid {
-			tool.ExecutionCount = executionCount.Int64
-		}
-		if lastExecutedAt.Valid {
-			tool.LastExecutedAt = &lastExecutedAt.Time
-		}
-		if avgExecutionTimeMs.Valid {
-			tool.AvgExecutionTimeMs = int(avgExecutionTimeMs.Int64)
-		}
-
-		// Compute similarity if embedding exists
-		if combinedEmbeddingStr.Valid && combinedEmbeddingStr.String != "" {
-			toolEmbedding := parseVector(combinedEmbeddingStr.String)
-			if len(toolEmbedding) > 0 {
-				score := cosineSimilarity(queryEmbedding, toolEmbedding)
-				toolsWithScores = append(toolsWithScores, toolWithScore{
-					tool:  &tool,
-					score: score,
-				})
-			}
-		}
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating tools: %w", err)
-	}
-
-	// Sort by similarity score descending
-	sort.Slice(toolsWithScores, func(i, j int) bool {
-		return toolsWithScores[i].score > toolsWithScores[j].score
-	})
-
-	// Return top results
-	if limit > len(toolsWithScores) {
-		limit = len(toolsWithScores)
-	}
-
-	result := make([]*domain.MCPTool, limit)
-	for i := 0; i < limit; i++ {
-		result[i] = toolsWithScores[i].tool
-	}
-
-	return result, nil
+	tools, _, err := s.SearchToolsByVectorWithScores(ctx, queryEmbedding, "", limit)
+	return tools, err
 }
 
 // SearchToolsByVectorWithScores performs semantic search and returns tools with their similarity scores
-func (s *TenantStore) SearchToolsByVectorWithScores(ctx context.Context, queryEmbedding []float32, limit int) ([]*domain.MCPTool, []float64, error) {
+// Only tools the role may search (ALLOW or SEARCH) are returned; an empty roleID searches every tool
+// Uses the pgvector HNSW index when available, otherwise computes cosine similarity in memory
+func (s *TenantStore) SearchToolsByVectorWithScores(ctx context.Context, queryEmbedding []float32, roleID string, limit int) ([]*domain.MCPTool, []float64, error) {
 	if len(queryEmbedding) == 0 {
 		return nil, nil, fmt.Errorf("embedding is empty")
 	}
 
+	if dims := s.toolVectorDimensions(ctx); dims > 0 && len(queryEmbedding) == dims {
+		tools, scores, err := s.searchToolsByANN(ctx, queryEmbedding, roleID, limit)
+		if err == nil {
+			return tools, scores, nil
+		}
+		slog.Warn("pgvector tool search failed, falling back to in-memory search", "error", err)
+	}
+
 	// Fetch all tools with embeddings
 	query := `
 		SELECT t.id, t.server_id, s.name as server_name,
@@ -1167,9 +1101,9 @@ func (s *TenantStore) SearchToolsByVectorWithScores(ctx context.Context, queryEm
 		WHERE t.combined_embedding IS NOT NULL
 			AND t.combined_embedding != ''
 			AND t.is_deprecated = FALSE
-	`
+			AND ` + fmt.Sprintf(searchableByRole, 1)
 
-	rows, err := s.db.QueryContext(ctx, query)
+	rows, err := s.db.QueryContext(ctx, query, nullString(roleID))
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to query tools: %w", err)
 	}
@@ -1318,19 +1252,19 @@ func (s *TenantStore) LogMCPToolExecution(ctx context.Context, exec *domain.MCPT
 			id, server_id, tool_id,
 			role_id, api_key_id, request_id,
 			input_params, output_result, status, error_message,
-			started_at, completed_at, duration_ms
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+			started_at, completed_at, duration_ms, cost_usd
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 	`
 
 	_, err := s.db.ExecContext(ctx, query,
 		exec.ID, exec.ServerID, exec.ToolID,
 		exec.RoleID, exec.APIKeyID, exec.RequestID,
 		inputParams, outputResult, exec.Status, exec.ErrorMessage,
-		exec.StartedAt, exec.CompletedAt, exec.DurationMs,
+		exec.StartedAt, exec.CompletedAt, exec.DurationMs, exec.CostUSD,
 	)
 
-	// Update tool execution stats
-	if err == nil {
+	// Update tool execution stats; blocked calls never ran
+	if err == nil && exec.Status != domain.MCPExecBlocked {
 		s.updateToolExecutionStats(ctx, exec.ToolID, exec.DurationMs)
 	}
 
@@ -1350,23 +1284,66 @@ func (s *TenantStore) updateToolExecutionStats(ctx context.Context, toolID strin
 	s.db.ExecContext(ctx, query, toolID, durationMs)
 }
 
-// ListMCPToolExecutions lists tool executions
-func (s *TenantStore) ListMCPToolExecutions(ctx context.Context, limit, offset int) ([]*domain.MCPToolExecution, int, error) {
-	countQuery := "SELECT COUNT(*) FROM mcp_tool_executions"
+// GetMCPToolUsage returns a role's tool calls and spend for the current
+// minute, UTC day and month, and when it last called the given tool
+func (s *TenantStore) GetMCPToolUsage(ctx context.Context, roleID, toolID string, now time.Time) (*domain.MCPToolUsage, error) {
+	now = now.UTC()
+	minuteAgo := now.Add(-time.Minute)
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	var usage domain.MCPToolUsage
+	var lastToolCall sql.NullTime
+	err := s.db.QueryRowContext(ctx, `
+		SELECT
+			COUNT(*) FILTER (WHERE started_at > $2),
+			COUNT(*) FILTER (WHERE started_at >= $3),
+			MAX(started_at) FILTER (WHERE tool_id = $5),
+			COALESCE(SUM(cost_usd) FILTER (WHERE started_at >= $3), 0),
+			COALESCE(SUM(cost_usd) FILTER (WHERE started_at >= $4), 0)
+		FROM mcp_tool_executions
+		WHERE role_id = $1 AND status <> $6 AND started_at >= LEAST($2, $4)
+	`, roleID, minuteAgo, dayStart, monthStart, toolID, domain.MCPExecBlocked).Scan(
+		&usage.LastMinute, &usage.Today, &lastToolCall, &usage.CostToday, &usage.CostMonth)
+	if err != nil {
+		return nil, err
+	}
+	if lastToolCall.Valid {
+		usage.LastToolCall = &lastToolCall.Time
+	}
+	return &usage, nil
+}
+
+// maxMCPExecutionPage is the most tool executions ListMCPToolExecutions returns at once
+const maxMCPExecutionPage = 500
+
+// ListMCPToolExecutions lists the tool executions matching filter, newest
+// first. limit defaults to 50 and is capped at maxMCPExecutionPage.
+func (s *TenantStore) ListMCPToolExecutions(ctx context.Context, filter domain.MCPToolExecutionFilter, limit, offset int) ([]*domain.MCPToolExecution, int, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	limit = min(limit, maxMCPExecutionPage)
+	offset = max(offset, 0)
+	whereClause, args := mcpExecutionWhere(filter)
+
 	var total int
-	s.db.QueryRowContext(ctx, countQuery).Scan(&total)
+	err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM mcp_tool_executions e "+whereClause, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
 
 	query := `
-		SELECT id, server_id, tool_id,
-			role_id, api_key_id, request_id,
-			input_params, output_result, status, error_message,
-			started_at, completed_at, duration_ms, created_at
-		FROM mcp_tool_executions
-		ORDER BY created_at DESC
-		LIMIT $1 OFFSET $2
-	`
-
-	rows, err := s.db.QueryContext(ctx, query, limit, offset)
+		SELECT e.id, e.server_id, e.tool_id, COALESCE(srv.name, ''), COALESCE(t.name, ''),
+			e.role_id, e.api_key_id, e.request_id,
+			e.input_params, e.output_result, e.status, e.error_message,
+			e.started_at, e.completed_at, e.duration_ms, e.cost_usd, e.created_at
+		FROM mcp_tool_executions e
+		LEFT JOIN mcp_servers srv ON srv.id = e.server_id
+		LEFT JOIN mcp_tools t ON t.id = e.tool_id
+	` + whereClause + fmt.Sprintf(" ORDER BY e.started_at DESC LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2)
+
+	rows, err := s.db.QueryContext(ctx, query, append(args, limit, offset)...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -1380,10 +1357,10 @@ func (s *TenantStore) ListMCPToolExecutions(ctx context.Context, limit, offset i
 		var completedAt sql.NullTime
 
 		err := rows.Scan(
-			&e.ID, &e.ServerID, &e.ToolID,
+			&e.ID, &e.ServerID, &e.ToolID, &e.ServerName, &e.ToolName,
 			&roleID, &apiKeyID, &requestID,
 			&inputParams, &outputResult, &e.Status, &errorMessage,
-			&e.StartedAt, &completedAt, &e.DurationMs, &e.CreatedAt,
+			&e.StartedAt, &completedAt, &e.DurationMs, &e.CostUSD, &e.CreatedAt,
 		)
 		if err != nil {
 			return nil, 0, err
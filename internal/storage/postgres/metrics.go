@@ -84,7 +84,7 @@ func (s *TenantStore) GetCacheMetrics(ctx context.Context) (*CacheMetrics, error
 		FROM semantic_cache
 		WHERE expires_at > NOW()
 	`
-	err := s.db.QueryRowContext(ctx, cacheQuery).Scan(
+	err := s.analytics().QueryRowContext(ctx, cacheQuery).Scan(
 		&metrics.Entries,
 		&metrics.TokensSaved,
 		&metrics.CostSaved,
@@ -102,11 +102,11 @@ func (s *TenantStore) GetCacheMetrics(ctx context.Context) (*CacheMetrics, error
 			COALESCE(COUNT(*) FILTER (WHERE hit = false), 0) as misses
 		FROM cache_events
 	`
-	err = s.db.QueryRowContext(ctx, eventsQuery).Scan(&metrics.Hits, &metrics.Misses)
+	err = s.analytics().QueryRowContext(ctx, eventsQuery).Scan(&metrics.Hits, &metrics.Misses)
 	if err != nil && err != sql.ErrNoRows {
 		// Fallback to semantic_cache hit_count if cache_events fails
 		fallbackQuery := `SELECT COALESCE(SUM(hit_count), 0) FROM semantic_cache WHERE expires_at > NOW()`
-		_ = s.db.QueryRowContext(ctx, fallbackQuery).Scan(&metrics.Hits)
+		_ = s.analytics().QueryRowContext(ctx, fallbackQuery).Scan(&metrics.Hits)
 	}
 
 	// Calculate hit rate
@@ -136,7 +136,7 @@ func (s *TenantStore) GetRoutingMetrics(ctx context.Context) (*RoutingMetrics, e
 			COALESCE(SUM(CASE WHEN success = false THEN 1 ELSE 0 END), 0) as failures
 		FROM routing_decisions
 	`
-	err := s.db.QueryRowContext(ctx, totalQuery).Scan(&metrics.Decisions, &metrics.Failures)
+	err := s.analytics().QueryRowContext(ctx, totalQuery).Scan(&metrics.Decisions, &metrics.Failures)
 	if err != nil && err != sql.ErrNoRows {
 		return nil, err
 	}
@@ -148,7 +148,7 @@ func (s *TenantStore) GetRoutingMetrics(ctx context.Context) (*RoutingMetrics, e
 		GROUP BY strategy
 		ORDER BY count DESC
 	`
-	rows, err := s.db.QueryContext(ctx, strategyQuery)
+	rows, err := s.analytics().QueryContext(ctx, strategyQuery)
 	if err != nil && err != sql.ErrNoRows {
 		return nil, err
 	}
@@ -185,7 +185,7 @@ func (s *TenantStore) GetResilienceMetrics(ctx context.Context) (*ResilienceMetr
 		FROM circuit_breaker_state
 		ORDER BY provider
 	`
-	rows, err := s.db.QueryContext(ctx, cbQuery)
+	rows, err := s.analytics().QueryContext(ctx, cbQuery)
 	if err != nil && err != sql.ErrNoRows {
 		// Table might not exist
 		metrics.CircuitBreakers = []CircuitBreakerInfo{}
@@ -209,7 +209,7 @@ func (s *TenantStore) GetResilienceMetrics(ctx context.Context) (*ResilienceMetr
 		FROM fallback_events
 	`
 	var total, successes int
-	err = s.db.QueryRowContext(ctx, fallbackQuery).Scan(&total, &successes)
+	err = s.analytics().QueryRowContext(ctx, fallbackQuery).Scan(&total, &successes)
 	if err == nil {
 		metrics.FallbackInvocations = total
 		if total > 0 {
@@ -241,11 +241,11 @@ func (s *TenantStore) GetProviderHealthMetrics(ctx context.Context) ([]ProviderH
 			AVG(CASE WHEN is_success THEN 1.0 ELSE 0.0 END) as success_rate,
 			COALESCE(PERCENTILE_CONT(0.95) WITHIN GROUP (ORDER BY latency_ms), 0) as p95_latency
 		FROM usage_records
-		WHERE created_at >= NOW() - INTERVAL '24 hours'
+		WHERE created_at >= NOW() - INTERVAL '24 hours' AND deleted_at IS NULL
 		GROUP BY provider, model
 		ORDER BY requests DESC
 	`
-	rows, err := s.db.QueryContext(ctx, query)
+	rows, err := s.analytics().QueryContext(ctx, query)
 	if err != nil {
 		// If query fails, return empty metrics
 		return metrics, nil
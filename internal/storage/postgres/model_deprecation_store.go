@@ -0,0 +1,89 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+// ============================================================================
+// Model Deprecations
+// ============================================================================
+
+// ListModelDeprecations returns every catalog model with a sunset date,
+// soonest first
+func (s *TenantStore) ListModelDeprecations(ctx context.Context) ([]*domain.ModelDeprecation, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT provider, model_id, sunset_at, COALESCE(replacement_model, ''),
+		       COALESCE(deprecation_notice, ''), sunset_alerted_at
+		FROM available_models
+		WHERE sunset_at IS NOT NULL
+		ORDER BY sunset_at, provider, model_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deprecations []*domain.ModelDeprecation
+	for rows.Next() {
+		var d domain.ModelDeprecation
+		var alertedAt sql.NullTime
+		if err := rows.Scan(&d.Provider, &d.ModelID, &d.SunsetAt, &d.ReplacementModel, &d.Notice, &alertedAt); err != nil {
+			return nil, err
+		}
+		if alertedAt.Valid {
+			d.AlertedAt = &alertedAt.Time
+		}
+		deprecations = append(deprecations, &d)
+	}
+	return deprecations, rows.Err()
+}
+
+// SetModelDeprecation records a model's sunset date and replacement. A new
+// sunset date clears the record of the alert sent for the old one. Returns
+// false when the model isn't in the catalog.
+func (s *TenantStore) SetModelDeprecation(ctx context.Context, d *domain.ModelDeprecation) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE available_models SET
+			sunset_alerted_at = CASE WHEN sunset_at IS DISTINCT FROM $3 THEN NULL ELSE sunset_alerted_at END,
+			sunset_at = $3,
+			replacement_model = $4,
+			deprecation_notice = $5,
+			updated_at = NOW()
+		WHERE provider = $1 AND model_id = $2
+	`, string(d.Provider), d.ModelID, d.SunsetAt, nullString(d.ReplacementModel), nullString(d.Notice))
+	if err != nil {
+		return false, fmt.Errorf("set model deprecation: %w", err)
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ClearModelDeprecation removes a model's sunset date and replacement.
+// Returns false when the model had none.
+func (s *TenantStore) ClearModelDeprecation(ctx context.Context, provider domain.Provider, modelID string) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE available_models SET
+			sunset_at = NULL, replacement_model = NULL, deprecation_notice = NULL,
+			sunset_alerted_at = NULL, updated_at = NOW()
+		WHERE provider = $1 AND model_id = $2 AND sunset_at IS NOT NULL
+	`, string(provider), modelID)
+	if err != nil {
+		return false, fmt.Errorf("clear model deprecation: %w", err)
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// MarkSunsetAlerted records that admins were alerted about a model's sunset
+func (s *TenantStore) MarkSunsetAlerted(ctx context.Context, provider domain.Provider, modelID string, at time.Time) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE available_models SET sunset_alerted_at = $3
+		WHERE provider = $1 AND model_id = $2
+	`, string(provider), modelID, at)
+	return err
+}
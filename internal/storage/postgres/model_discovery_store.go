@@ -0,0 +1,73 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"modelgate/internal/domain"
+
+	"github.com/lib/pq"
+)
+
+// ============================================================================
+// Model Discovery
+// ============================================================================
+
+// ListCatalogModels returns every available_models entry of a provider,
+// including ones no longer available
+func (s *TenantStore) ListCatalogModels(ctx context.Context, provider string) ([]domain.CatalogModel, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT model_id, COALESCE(is_available, false), COALESCE(is_deprecated, false), fetched_at
+		FROM available_models WHERE provider = $1
+		ORDER BY model_id
+	`, provider)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var models []domain.CatalogModel
+	for rows.Next() {
+		var m domain.CatalogModel
+		if err := rows.Scan(&m.ID, &m.Available, &m.Deprecated, &m.FetchedAt); err != nil {
+			return nil, err
+		}
+		models = append(models, m)
+	}
+	return models, rows.Err()
+}
+
+// SyncCatalogModels applies a discovery run to a provider's catalog in one
+// transaction: listed models are upserted as available, deprecated ones are
+// flagged and removed ones marked unavailable. Listed models' fetched_at
+// moves to now; the others keep the time they were last listed.
+func (s *TenantStore) SyncCatalogModels(ctx context.Context, provider string, listed []domain.ModelInfo, deprecated, removed []string) error {
+	tx, err := s.beginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, model := range listed {
+		if err := upsertAvailableModel(ctx, tx, model); err != nil {
+			return err
+		}
+	}
+	if len(deprecated) > 0 {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE available_models SET is_deprecated = true, updated_at = NOW()
+			WHERE provider = $1 AND model_id = ANY($2)
+		`, provider, pq.Array(deprecated)); err != nil {
+			return fmt.Errorf("flag deprecated models: %w", err)
+		}
+	}
+	if len(removed) > 0 {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE available_models SET is_available = false, updated_at = NOW()
+			WHERE provider = $1 AND model_id = ANY($2)
+		`, provider, pq.Array(removed)); err != nil {
+			return fmt.Errorf("remove models: %w", err)
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,80 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"modelgate/internal/domain"
+)
+
+// ============================================================================
+// Model pricing history
+// ============================================================================
+
+// ListModelPrices returns pricing history entries, newest effective date first
+func (s *TenantStore) ListModelPrices(ctx context.Context, filter domain.ModelPriceFilter) ([]*domain.ModelPrice, error) {
+	query := `
+		SELECT id, provider, model_id, input_cost_per_1m, output_cost_per_1m, cached_input_cost_per_1m, source, reset,
+			effective_from, note, created_by, created_at
+		FROM model_prices
+		WHERE 1=1
+	`
+	args := []interface{}{}
+	argIndex := 1
+
+	if filter.Provider != "" {
+		query += fmt.Sprintf(" AND provider = $%d", argIndex)
+		args = append(args, string(filter.Provider))
+		argIndex++
+	}
+	if filter.ModelID != "" {
+		query += fmt.Sprintf(" AND model_id = $%d", argIndex)
+		args = append(args, filter.ModelID)
+		argIndex++
+	}
+	if filter.Source != "" {
+		query += fmt.Sprintf(" AND source = $%d", argIndex)
+		args = append(args, string(filter.Source))
+	}
+
+	query += " ORDER BY effective_from DESC, created_at DESC"
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var prices []*domain.ModelPrice
+	for rows.Next() {
+		var p domain.ModelPrice
+		var provider, source string
+		var note, createdBy sql.NullString
+		if err := rows.Scan(&p.ID, &provider, &p.ModelID, &p.InputCostPer1M, &p.OutputCostPer1M, &p.CachedInputCostPer1M, &source, &p.Reset,
+			&p.EffectiveFrom, &note, &createdBy, &p.CreatedAt); err != nil {
+			return nil, err
+		}
+		p.Provider = domain.Provider(provider)
+		p.Source = domain.PriceSource(source)
+		p.Note = note.String
+		p.CreatedBy = createdBy.String
+		prices = append(prices, &p)
+	}
+	return prices, rows.Err()
+}
+
+// CreateModelPrice appends an entry to the pricing history
+func (s *TenantStore) CreateModelPrice(ctx context.Context, price *domain.ModelPrice) error {
+	return s.db.QueryRowContext(ctx, `
+		INSERT INTO model_prices (provider, model_id, input_cost_per_1m, output_cost_per_1m, cached_input_cost_per_1m,
+			source, reset, effective_from, note, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, created_at
+	`, string(price.Provider), price.ModelID, price.InputCostPer1M, price.OutputCostPer1M, price.CachedInputCostPer1M,
+		string(price.Source), price.Reset,
+		price.EffectiveFrom,
+		sql.NullString{String: price.Note, Valid: price.Note != ""},
+		sql.NullString{String: price.CreatedBy, Valid: price.CreatedBy != ""},
+	).Scan(&price.ID, &price.CreatedAt)
+}
@@ -0,0 +1,292 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"modelgate/internal/config"
+	"modelgate/internal/passwords"
+)
+
+// ============================================================================
+// User Passwords
+// ============================================================================
+
+var defaultPasswordPolicy = passwords.NewPolicy(config.Default().Passwords)
+
+// SetPasswordPolicy sets the policy user passwords are hashed with and held to
+func (s *TenantStore) SetPasswordPolicy(policy *passwords.Policy) {
+	s.passwords = policy
+}
+
+func (s *TenantStore) passwordPolicy() *passwords.Policy {
+	if s.passwords == nil {
+		return defaultPasswordPolicy
+	}
+	return s.passwords
+}
+
+// userCredentials is a user's password state
+type userCredentials struct {
+	user         *TenantUser
+	passwordHash string
+	changedAt    time.Time
+	mustChange   bool
+	lockedUntil  sql.NullTime
+}
+
+func (s *TenantStore) getUserCredentials(ctx context.Context, email string) (*userCredentials, error) {
+	var c userCredentials
+	var user TenantUser
+	var metadataJSON []byte
+	var createdBy, createdByEmail sql.NullString
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, email, password_hash, name, role, is_active, last_login_at, metadata, created_by, created_by_email,
+		       created_at, updated_at, password_changed_at, must_change_password, locked_until
+		FROM users WHERE email = $1
+	`, email).Scan(&user.ID, &user.Email, &c.passwordHash, &user.Name, &user.Role, &user.IsActive, &user.LastLoginAt,
+		&metadataJSON, &createdBy, &createdByEmail, &user.CreatedAt, &user.UpdatedAt, &c.changedAt, &c.mustChange, &c.lockedUntil)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	json.Unmarshal(metadataJSON, &user.Metadata)
+	user.CreatedBy = createdBy.String
+	user.CreatedByEmail = createdByEmail.String
+	c.user = &user
+	return &c, nil
+}
+
+// authenticate checks a user's password. A wrong one counts towards locking
+// the account; a right one starts the count over, and replaces the hash if it
+// was made with another algorithm or other parameters than the policy's.
+func (s *TenantStore) authenticate(ctx context.Context, email, password string) (*userCredentials, error) {
+	policy := s.passwordPolicy()
+	c, err := s.getUserCredentials(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		policy.VerifyUnknown(password)
+		return nil, passwords.ErrInvalidCredentials
+	}
+	if !c.user.IsActive {
+		return nil, fmt.Errorf("user is not active")
+	}
+	now := time.Now()
+	if c.lockedUntil.Valid && c.lockedUntil.Time.After(now) {
+		return nil, &passwords.LockedError{Until: c.lockedUntil.Time}
+	}
+
+	ok, rehash := policy.Verify(c.passwordHash, password)
+	if !ok {
+		if until, err := s.recordFailure(ctx, c.user.ID, "failed_login_count", now); err != nil {
+			return nil, err
+		} else if !until.IsZero() {
+			return nil, &passwords.LockedError{Until: until}
+		}
+		return nil, passwords.ErrInvalidCredentials
+	}
+
+	// Lockouts for wrong two-factor codes keep growing until a code is accepted
+	if _, err := s.db.ExecContext(ctx, `
+		UPDATE users SET failed_login_count = 0, locked_until = NULL,
+			lockout_count = CASE WHEN two_factor_failures = 0 THEN 0 ELSE lockout_count END
+		WHERE id = $1
+	`, c.user.ID); err != nil {
+		return nil, err
+	}
+	if rehash {
+		if hash, err := policy.Hash(password); err == nil {
+			s.db.ExecContext(ctx, "UPDATE users SET password_hash = $1 WHERE id = $2 AND password_hash = $3", hash, c.user.ID, c.passwordHash)
+			c.passwordHash = hash
+		}
+	}
+	return c, nil
+}
+
+// recordFailure counts a failed login in counter, failed_login_count or
+// two_factor_failures, and locks the account once the count reaches the
+// lockout threshold. It returns when the lock ends, or zero if the account
+// isn't locked.
+func (s *TenantStore) recordFailure(ctx context.Context, userID, counter string, now time.Time) (time.Time, error) {
+	cfg := s.passwordPolicy().Config()
+	var failed, lockouts int
+	if err := s.db.QueryRowContext(ctx, `
+		UPDATE users SET `+counter+` = `+counter+` + 1 WHERE id = $1
+		RETURNING `+counter+`, lockout_count
+	`, userID).Scan(&failed, &lockouts); err != nil {
+		return time.Time{}, err
+	}
+	if cfg.LockoutThreshold == 0 || failed < cfg.LockoutThreshold {
+		return time.Time{}, nil
+	}
+
+	until := now.Add(s.passwordPolicy().LockoutDuration(lockouts + 1))
+	// Concurrent failures past the threshold lock the account once
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE users SET `+counter+` = 0, lockout_count = lockout_count + 1, locked_until = $2
+		WHERE id = $1 AND `+counter+` >= $3
+	`, userID, until, cfg.LockoutThreshold)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return time.Time{}, nil
+	}
+	return until, nil
+}
+
+// ValidateUserPassword validates a user's password for a login. A user whose
+// password has expired, or who was given a temporary one, gets
+// passwords.ErrPasswordExpired or passwords.ErrPasswordChangeRequired and
+// must use ChangeUserPassword instead.
+func (s *TenantStore) ValidateUserPassword(ctx context.Context, email, password string) (*TenantUser, error) {
+	c, err := s.authenticate(ctx, email, password)
+	if err != nil {
+		return nil, err
+	}
+	if c.mustChange {
+		return nil, passwords.ErrPasswordChangeRequired
+	}
+	if s.passwordPolicy().Expired(c.changedAt, time.Now()) {
+		return nil, passwords.ErrPasswordExpired
+	}
+
+	// Update last login
+	s.db.ExecContext(ctx, "UPDATE users SET last_login_at = $1 WHERE id = $2", time.Now(), c.user.ID)
+
+	return c.user, nil
+}
+
+// AuthenticateUser checks a user's password, as a login does, for actions
+// that need it again, such as setting up two-factor authentication. Unlike a
+// login, it accepts expired and temporary passwords.
+func (s *TenantStore) AuthenticateUser(ctx context.Context, email, password string) (*TenantUser, error) {
+	c, err := s.authenticate(ctx, email, password)
+	if err != nil {
+		return nil, err
+	}
+	return c.user, nil
+}
+
+// ChangeUserPassword replaces a user's password after checking the current
+// one, and logs them in. It works for expired and temporary passwords.
+func (s *TenantStore) ChangeUserPassword(ctx context.Context, email, currentPassword, newPassword string) (*TenantUser, error) {
+	c, err := s.authenticate(ctx, email, currentPassword)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.setPassword(ctx, c.user, c.passwordHash, newPassword, false); err != nil {
+		return nil, err
+	}
+	s.db.ExecContext(ctx, "UPDATE users SET last_login_at = $1 WHERE id = $2", time.Now(), c.user.ID)
+	return c.user, nil
+}
+
+// SetTemporaryPassword gives a user a password they must change at their
+// next login, and unlocks their account
+func (s *TenantStore) SetTemporaryPassword(ctx context.Context, userID, password string) (*TenantUser, error) {
+	user, err := s.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, fmt.Errorf("user not found")
+	}
+	var currentHash string
+	if err := s.db.QueryRowContext(ctx, "SELECT password_hash FROM users WHERE id = $1", userID).Scan(&currentHash); err != nil {
+		return nil, err
+	}
+	if err := s.setPassword(ctx, user, currentHash, password, true); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// setPassword checks a new password against the policy and the user's
+// previous passwords, stores it, and keeps the replaced hash in the history
+func (s *TenantStore) setPassword(ctx context.Context, user *TenantUser, currentHash, password string, mustChange bool) error {
+	policy := s.passwordPolicy()
+	if err := policy.Check(password, user.Email, user.Name); err != nil {
+		return err
+	}
+	history := policy.Config().History
+	if history > 0 {
+		rows, err := s.db.QueryContext(ctx, `
+			SELECT password_hash FROM user_password_history WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2
+		`, user.ID, history-1)
+		if err != nil {
+			return err
+		}
+		previous := []string{currentHash}
+		for rows.Next() {
+			var hash string
+			if err := rows.Scan(&hash); err != nil {
+				rows.Close()
+				return err
+			}
+			previous = append(previous, hash)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		for _, hash := range previous {
+			if ok, _ := policy.Verify(hash, password); ok {
+				return passwords.ErrPasswordReused
+			}
+		}
+	}
+
+	hash, err := policy.Hash(password)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+	tx, err := s.beginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE users SET password_hash = $2, password_changed_at = NOW(), must_change_password = $3,
+			failed_login_count = 0, lockout_count = 0, locked_until = NULL, updated_at = NOW()
+		WHERE id = $1
+	`, user.ID, hash, mustChange); err != nil {
+		return err
+	}
+	if history > 0 {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO user_password_history (user_id, password_hash) VALUES ($1, $2)
+		`, user.ID, currentHash); err != nil {
+			return err
+		}
+	}
+	// Only the hashes a new password is checked against are kept
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM user_password_history WHERE user_id = $1 AND id NOT IN (
+			SELECT id FROM user_password_history WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2)
+	`, user.ID, max(history-1, 0)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UnlockUser ends a user's lockout and clears their failed logins. It
+// returns false if the user doesn't exist.
+func (s *TenantStore) UnlockUser(ctx context.Context, userID string) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE users SET failed_login_count = 0, two_factor_failures = 0, lockout_count = 0, locked_until = NULL WHERE id = $1
+	`, userID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
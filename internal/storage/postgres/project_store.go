@@ -0,0 +1,168 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+// ============================================================================
+// Projects
+// ============================================================================
+
+const projectColumns = `p.id, p.name, p.description, p.budget_policy, p.created_by, p.created_by_email,
+	p.created_at, p.updated_at,
+	(SELECT COUNT(*) FROM api_keys k WHERE k.project_id = p.id AND NOT k.is_revoked)`
+
+func scanProject(row interface{ Scan(...any) error }) (*domain.Project, error) {
+	var p domain.Project
+	var budget []byte
+	var createdBy, createdByEmail sql.NullString
+	if err := row.Scan(&p.ID, &p.Name, &p.Description, &budget, &createdBy, &createdByEmail,
+		&p.CreatedAt, &p.UpdatedAt, &p.APIKeyCount); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(budget, &p.BudgetPolicy); err != nil {
+		return nil, fmt.Errorf("decode budget policy of project %s: %w", p.ID, err)
+	}
+	p.CreatedBy, p.CreatedByEmail = createdBy.String, createdByEmail.String
+	return &p, nil
+}
+
+// CreateProject inserts a project, filling in its ID and timestamps
+func (s *TenantStore) CreateProject(ctx context.Context, p *domain.Project) error {
+	budget, err := json.Marshal(p.BudgetPolicy)
+	if err != nil {
+		return err
+	}
+	return s.db.QueryRowContext(ctx, `
+		INSERT INTO projects (name, description, budget_policy, created_by, created_by_email)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at, updated_at
+	`, p.Name, p.Description, budget, nullString(p.CreatedBy), nullString(p.CreatedByEmail)).Scan(&p.ID, &p.CreatedAt, &p.UpdatedAt)
+}
+
+// GetProject returns a project, or nil if it does not exist
+func (s *TenantStore) GetProject(ctx context.Context, id string) (*domain.Project, error) {
+	p, err := scanProject(s.db.QueryRowContext(ctx, `
+		SELECT `+projectColumns+`
+		FROM projects p
+		WHERE p.id = $1
+	`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return p, err
+}
+
+// ListProjects returns all projects ordered by name
+func (s *TenantStore) ListProjects(ctx context.Context) ([]*domain.Project, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+projectColumns+`
+		FROM projects p
+		ORDER BY p.name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var projects []*domain.Project
+	for rows.Next() {
+		p, err := scanProject(rows)
+		if err != nil {
+			return nil, err
+		}
+		projects = append(projects, p)
+	}
+	return projects, rows.Err()
+}
+
+// UpdateProject saves a project's name, description and budget policy
+func (s *TenantStore) UpdateProject(ctx context.Context, p *domain.Project) error {
+	budget, err := json.Marshal(p.BudgetPolicy)
+	if err != nil {
+		return err
+	}
+	return s.db.QueryRowContext(ctx, `
+		UPDATE projects
+		SET name = $2, description = $3, budget_policy = $4
+		WHERE id = $1
+		RETURNING updated_at
+	`, p.ID, p.Name, p.Description, budget).Scan(&p.UpdatedAt)
+}
+
+// DeleteProject deletes a project. Its API keys are detached from it and its
+// usage records keep the project ID. Returns false if it did not exist.
+func (s *TenantStore) DeleteProject(ctx context.Context, id string) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM projects WHERE id = $1`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// UpdateAPIKeyProject moves an API key into a project (empty projectID removes it from its project)
+func (s *TenantStore) UpdateAPIKeyProject(ctx context.Context, keyID, projectID string) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE api_keys SET project_id = $2 WHERE id = $1`, keyID, nullString(projectID))
+	return err
+}
+
+// GetProjectSpend returns a project's cost since the start of each budget window
+func (s *TenantStore) GetProjectSpend(ctx context.Context, projectID string, dayStart, weekStart, monthStart time.Time) (*domain.ProjectSpend, error) {
+	// The scan starts at the earliest window; the week can begin in the previous month
+	from := monthStart
+	if weekStart.Before(from) {
+		from = weekStart
+	}
+	var spend domain.ProjectSpend
+	err := s.db.QueryRowContext(ctx, `
+		SELECT
+			COALESCE(SUM(cost_usd) FILTER (WHERE created_at >= $2), 0),
+			COALESCE(SUM(cost_usd) FILTER (WHERE created_at >= $3), 0),
+			COALESCE(SUM(cost_usd) FILTER (WHERE created_at >= $4), 0)
+		FROM usage_records
+		WHERE project_id = $1 AND created_at >= $5 AND deleted_at IS NULL
+	`, projectID, dayStart, weekStart, monthStart, from).Scan(&spend.DailyUSD, &spend.WeeklyUSD, &spend.MonthlyUSD)
+	if err != nil {
+		return nil, err
+	}
+	return &spend, nil
+}
+
+// GetUsageStatsByProject gets usage statistics grouped by project. Usage of
+// deleted projects is reported under their ID with an empty name.
+func (s *TenantStore) GetUsageStatsByProject(ctx context.Context, startTime, endTime time.Time) ([]*domain.ProjectUsageStats, error) {
+	rows, err := s.analytics().QueryContext(ctx, `
+		SELECT
+			ur.project_id,
+			COALESCE(p.name, '') as project_name,
+			COUNT(*) as requests,
+			COALESCE(SUM(ur.total_tokens), 0) as total_tokens,
+			COALESCE(SUM(ur.cost_usd), 0) as cost_usd
+		FROM usage_records ur
+		LEFT JOIN projects p ON ur.project_id = p.id
+		WHERE ur.created_at >= $1 AND ur.created_at <= $2 AND ur.deleted_at IS NULL AND ur.project_id IS NOT NULL
+		GROUP BY ur.project_id, p.name
+		ORDER BY cost_usd DESC
+	`, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []*domain.ProjectUsageStats
+	for rows.Next() {
+		var ps domain.ProjectUsageStats
+		if err := rows.Scan(&ps.ProjectID, &ps.ProjectName, &ps.Requests, &ps.TotalTokens, &ps.CostUSD); err != nil {
+			return nil, err
+		}
+		stats = append(stats, &ps)
+	}
+	return stats, rows.Err()
+}
@@ -0,0 +1,150 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+// ============================================================================
+// Provider Health History
+// ============================================================================
+
+// successWeighted averages a latency column over rows, weighted by their
+// successful requests, the ones latencies are measured on
+func successWeighted(col string) string {
+	return fmt.Sprintf("COALESCE(SUM(%s * (requests - failures)) / NULLIF(SUM(requests - failures), 0), 0)", col)
+}
+
+// mergeWeighted is successWeighted for an upsert of one row onto another
+func mergeWeighted(col string) string {
+	return fmt.Sprintf(`COALESCE((s.%[1]s * (s.requests - s.failures) + EXCLUDED.%[1]s * (EXCLUDED.requests - EXCLUDED.failures))
+		/ NULLIF((s.requests - s.failures) + (EXCLUDED.requests - EXCLUDED.failures), 0), 0)`, col)
+}
+
+// breakerSeverity orders breaker states so the worst can be taken with MAX
+const breakerSeverity = `CASE breaker_state WHEN 'open' THEN 2 WHEN 'half_open' THEN 1 ELSE 0 END`
+
+var breakerStates = [...]string{"closed", "half_open", "open"}
+
+// InsertProviderHealthSamples stores health samples. A sample for a bucket
+// another instance already wrote is merged into it.
+func (s *TenantStore) InsertProviderHealthSamples(ctx context.Context, samples []domain.ProviderHealthSample) error {
+	tx, err := s.beginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO provider_health_samples AS s (bucket_start, bucket_seconds, provider, model, requests, failures,
+			client_errors, p50_latency_ms, p95_latency_ms, p99_latency_ms, breaker_state, breaker_open_seconds)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (provider, model, bucket_start) DO UPDATE SET
+			p50_latency_ms = `+mergeWeighted("p50_latency_ms")+`,
+			p95_latency_ms = `+mergeWeighted("p95_latency_ms")+`,
+			p99_latency_ms = `+mergeWeighted("p99_latency_ms")+`,
+			requests = s.requests + EXCLUDED.requests,
+			failures = s.failures + EXCLUDED.failures,
+			client_errors = s.client_errors + EXCLUDED.client_errors,
+			breaker_state = CASE
+				WHEN 'open' IN (s.breaker_state, EXCLUDED.breaker_state) THEN 'open'
+				WHEN 'half_open' IN (s.breaker_state, EXCLUDED.breaker_state) THEN 'half_open'
+				ELSE 'closed' END,
+			breaker_open_seconds = GREATEST(s.breaker_open_seconds, EXCLUDED.breaker_open_seconds)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, h := range samples {
+		if _, err := stmt.ExecContext(ctx, h.BucketStart, h.BucketSeconds, h.Provider, h.Model, h.Requests, h.Failures,
+			h.ClientErrors, h.P50LatencyMs, h.P95LatencyMs, h.P99LatencyMs, h.BreakerState, h.BreakerOpenSeconds); err != nil {
+			return fmt.Errorf("insert health sample for %s/%s: %w", h.Provider, h.Model, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// ListProviderHealthSamples returns a provider/model's samples in [from, to),
+// oldest first, combined into buckets of step seconds. An empty model selects
+// the provider as a whole.
+func (s *TenantStore) ListProviderHealthSamples(ctx context.Context, provider, model string, from, to time.Time, step int) ([]domain.ProviderHealthSample, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT to_timestamp(floor(extract(epoch FROM bucket_start) / $5) * $5) AS bucket,
+		       SUM(requests), SUM(failures), SUM(client_errors),
+		       `+successWeighted("p50_latency_ms")+`,
+		       `+successWeighted("p95_latency_ms")+`,
+		       `+successWeighted("p99_latency_ms")+`,
+		       MAX(`+breakerSeverity+`), SUM(breaker_open_seconds)
+		FROM provider_health_samples
+		WHERE provider = $1 AND model = $2 AND bucket_start >= $3 AND bucket_start < $4
+		GROUP BY bucket
+		ORDER BY bucket
+	`, provider, model, from, to, step)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var samples []domain.ProviderHealthSample
+	for rows.Next() {
+		h := domain.ProviderHealthSample{Provider: provider, Model: model, BucketSeconds: step}
+		var severity int
+		if err := rows.Scan(&h.BucketStart, &h.Requests, &h.Failures, &h.ClientErrors,
+			&h.P50LatencyMs, &h.P95LatencyMs, &h.P99LatencyMs, &severity, &h.BreakerOpenSeconds); err != nil {
+			return nil, err
+		}
+		h.BreakerState = breakerStates[severity]
+		samples = append(samples, h)
+	}
+	return samples, rows.Err()
+}
+
+// ListProviderSLAReports totals each provider/model's samples in [from, to).
+// A bucket counts as down for as long as the provider's breaker was open, or
+// whole when less than degradedBelow of its requests succeeded. Percentages
+// and the target are left to the caller.
+func (s *TenantStore) ListProviderSLAReports(ctx context.Context, from, to time.Time, degradedBelow float64) ([]domain.ProviderSLAReport, error) {
+	rows, err := s.analytics().QueryContext(ctx, `
+		SELECT provider, model, SUM(requests), SUM(failures),
+		       `+successWeighted("p50_latency_ms")+`,
+		       `+successWeighted("p95_latency_ms")+`,
+		       `+successWeighted("p99_latency_ms")+`,
+		       SUM(breaker_open_seconds) / 60,
+		       SUM(GREATEST(breaker_open_seconds, CASE
+		           WHEN requests > 0 AND (requests - failures)::float8 / requests < $3 THEN bucket_seconds
+		           ELSE 0 END)) / 60
+		FROM provider_health_samples
+		WHERE bucket_start >= $1 AND bucket_start < $2
+		GROUP BY provider, model
+		ORDER BY provider, model
+	`, from, to, degradedBelow)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reports []domain.ProviderSLAReport
+	for rows.Next() {
+		r := domain.ProviderSLAReport{Month: from}
+		if err := rows.Scan(&r.Provider, &r.Model, &r.Requests, &r.Failures, &r.P50LatencyMs, &r.P95LatencyMs,
+			&r.P99LatencyMs, &r.BreakerOpenMinutes, &r.DowntimeMinutes); err != nil {
+			return nil, err
+		}
+		reports = append(reports, r)
+	}
+	return reports, rows.Err()
+}
+
+// DeleteProviderHealthSamples deletes samples of buckets before the cutoff
+func (s *TenantStore) DeleteProviderHealthSamples(ctx context.Context, cutoff time.Time) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM provider_health_samples WHERE bucket_start < $1`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,147 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+// ============================================================================
+// Billing period quotas
+// ============================================================================
+
+// GetQuotaLimits returns the configured quota limits, or nil if none were set
+func (s *TenantStore) GetQuotaLimits(ctx context.Context) (*domain.QuotaLimits, error) {
+	var limits domain.QuotaLimits
+	var updatedBy sql.NullString
+	err := s.db.QueryRowContext(ctx, `
+		SELECT requests_limit, tokens_limit, cost_limit_usd, updated_by, updated_at
+		FROM quota_limits
+	`).Scan(&limits.RequestsLimit, &limits.TokensLimit, &limits.CostLimitUSD, &updatedBy, &limits.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	limits.UpdatedBy = updatedBy.String
+	return &limits, nil
+}
+
+// SaveQuotaLimits replaces the configured quota limits
+func (s *TenantStore) SaveQuotaLimits(ctx context.Context, limits *domain.QuotaLimits) error {
+	return s.db.QueryRowContext(ctx, `
+		INSERT INTO quota_limits (id, requests_limit, tokens_limit, cost_limit_usd, updated_by, updated_at)
+		VALUES (TRUE, $1, $2, $3, NULLIF($4, ''), NOW())
+		ON CONFLICT (id) DO UPDATE SET
+			requests_limit = EXCLUDED.requests_limit,
+			tokens_limit = EXCLUDED.tokens_limit,
+			cost_limit_usd = EXCLUDED.cost_limit_usd,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = NOW()
+		RETURNING updated_at
+	`, limits.RequestsLimit, limits.TokensLimit, limits.CostLimitUSD, limits.UpdatedBy).Scan(&limits.UpdatedAt)
+}
+
+const quotaPeriodColumns = `id, period_start, period_end, requests_limit, tokens_limit, cost_limit_usd,
+	requests_used, tokens_used, cost_used_usd, closed_at`
+
+func scanQuotaPeriod(row interface{ Scan(...any) error }) (*domain.QuotaPeriod, error) {
+	var p domain.QuotaPeriod
+	var closedAt sql.NullTime
+	if err := row.Scan(&p.ID, &p.PeriodStart, &p.PeriodEnd, &p.RequestsLimit, &p.TokensLimit, &p.CostLimitUSD,
+		&p.RequestsUsed, &p.TokensUsed, &p.CostUsedUSD, &closedAt); err != nil {
+		return nil, err
+	}
+	if closedAt.Valid {
+		p.ClosedAt = &closedAt.Time
+	}
+	return &p, nil
+}
+
+// GetOpenQuotaPeriod returns the earliest period that has not been closed, or nil
+func (s *TenantStore) GetOpenQuotaPeriod(ctx context.Context) (*domain.QuotaPeriod, error) {
+	p, err := scanQuotaPeriod(s.db.QueryRowContext(ctx, `
+		SELECT `+quotaPeriodColumns+`
+		FROM quota_periods
+		WHERE closed_at IS NULL
+		ORDER BY period_start
+		LIMIT 1
+	`))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return p, err
+}
+
+// CreateQuotaPeriod opens a period. If another instance already opened the
+// same period it is left as is.
+func (s *TenantStore) CreateQuotaPeriod(ctx context.Context, p *domain.QuotaPeriod) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO quota_periods (id, period_start, period_end, requests_limit, tokens_limit, cost_limit_usd)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (period_start) DO NOTHING
+	`, p.ID, p.PeriodStart, p.PeriodEnd, p.RequestsLimit, p.TokensLimit, p.CostLimitUSD)
+	return err
+}
+
+// UpdateQuotaPeriodLimits sets the effective limits of an open period
+func (s *TenantStore) UpdateQuotaPeriodLimits(ctx context.Context, p *domain.QuotaPeriod) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE quota_periods
+		SET requests_limit = $2, tokens_limit = $3, cost_limit_usd = $4
+		WHERE id = $1 AND closed_at IS NULL
+	`, p.ID, p.RequestsLimit, p.TokensLimit, p.CostLimitUSD)
+	return err
+}
+
+// CloseQuotaPeriod records a period's final usage and marks it closed
+func (s *TenantStore) CloseQuotaPeriod(ctx context.Context, p *domain.QuotaPeriod) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE quota_periods
+		SET requests_used = $2, tokens_used = $3, cost_used_usd = $4, closed_at = NOW()
+		WHERE id = $1 AND closed_at IS NULL
+	`, p.ID, p.RequestsUsed, p.TokensUsed, p.CostUsedUSD)
+	return err
+}
+
+// ListQuotaPeriods returns the most recent periods, newest first
+func (s *TenantStore) ListQuotaPeriods(ctx context.Context, limit int) ([]*domain.QuotaPeriod, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+quotaPeriodColumns+`
+		FROM quota_periods
+		ORDER BY period_start DESC
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var periods []*domain.QuotaPeriod
+	for rows.Next() {
+		p, err := scanQuotaPeriod(rows)
+		if err != nil {
+			return nil, err
+		}
+		periods = append(periods, p)
+	}
+	return periods, rows.Err()
+}
+
+// GetUsageTotals returns the requests, tokens and cost recorded in [start, end).
+// Rows soft-deleted by retention but not yet purged still count as usage.
+func (s *TenantStore) GetUsageTotals(ctx context.Context, start, end time.Time) (*domain.UsageStats, error) {
+	var stats domain.UsageStats
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*), COALESCE(SUM(total_tokens), 0), COALESCE(SUM(cost_usd), 0)
+		FROM usage_records
+		WHERE created_at >= $1 AND created_at < $2
+	`, start, end).Scan(&stats.TotalRequests, &stats.TotalTokens, &stats.TotalCostUSD)
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
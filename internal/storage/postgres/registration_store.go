@@ -0,0 +1,157 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+// ============================================================================
+// Registration Requests
+// ============================================================================
+
+const registrationColumns = `id, organization_name, organization_email, admin_name, admin_email, password_hash,
+	slug, COALESCE(preferred_tier, ''), status, COALESCE(rejection_reason, ''), COALESCE(user_role, ''),
+	reviewed_at, COALESCE(reviewed_by, ''), requested_at, created_at, updated_at`
+
+func scanRegistrationRequest(row interface{ Scan(...any) error }) (*domain.RegistrationRequest, error) {
+	var r domain.RegistrationRequest
+	if err := row.Scan(&r.ID, &r.OrganizationName, &r.OrganizationEmail, &r.AdminName, &r.AdminEmail, &r.PasswordHash,
+		&r.Slug, &r.PreferredTier, &r.Status, &r.RejectionReason, &r.UserRole,
+		&r.ReviewedAt, &r.ReviewedBy, &r.RequestedAt, &r.CreatedAt, &r.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// CreateRegistrationRequest inserts a pending registration request, filling
+// in its ID and timestamps. It returns false if the email already has a
+// pending request.
+func (s *TenantStore) CreateRegistrationRequest(ctx context.Context, r *domain.RegistrationRequest) (bool, error) {
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO registration_requests (organization_name, organization_email, admin_name, admin_email,
+			password_hash, slug, preferred_tier, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, 'pending')
+		ON CONFLICT (lower(admin_email)) WHERE status = 'pending' DO NOTHING
+		RETURNING id, status, requested_at, created_at, updated_at
+	`, r.OrganizationName, r.OrganizationEmail, r.AdminName, r.AdminEmail, r.PasswordHash, r.Slug,
+		nullString(string(r.PreferredTier))).Scan(&r.ID, &r.Status, &r.RequestedAt, &r.CreatedAt, &r.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// GetRegistrationRequest returns a registration request, or nil if it does not exist
+func (s *TenantStore) GetRegistrationRequest(ctx context.Context, id string) (*domain.RegistrationRequest, error) {
+	r, err := scanRegistrationRequest(s.db.QueryRowContext(ctx,
+		`SELECT `+registrationColumns+` FROM registration_requests WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return r, err
+}
+
+// ListRegistrationRequests returns registration requests, oldest first. An
+// empty status returns all of them.
+func (s *TenantStore) ListRegistrationRequests(ctx context.Context, status string) ([]*domain.RegistrationRequest, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+registrationColumns+` FROM registration_requests
+		WHERE ($1 = '' OR status = $1)
+		ORDER BY requested_at
+	`, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var requests []*domain.RegistrationRequest
+	for rows.Next() {
+		r, err := scanRegistrationRequest(rows)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, r)
+	}
+	return requests, rows.Err()
+}
+
+// CountPendingRegistrations returns how many registration requests await review
+func (s *TenantStore) CountPendingRegistrations(ctx context.Context) (int, error) {
+	var n int
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM registration_requests WHERE status = 'pending'`).Scan(&n)
+	return n, err
+}
+
+// ReviewRegistrationRequest records the decision on a pending request,
+// returning false if it was no longer pending (e.g. reviewed by another admin)
+func (s *TenantStore) ReviewRegistrationRequest(ctx context.Context, id, status, reason, userRole, reviewedBy string) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE registration_requests
+		SET status = $2, rejection_reason = $3, user_role = $4, reviewed_by = $5, reviewed_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status = 'pending'
+	`, id, status, nullString(reason), nullString(userRole), reviewedBy)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ReleaseRegistrationRequest puts a request back in the queue after its
+// approval could not be completed
+func (s *TenantStore) ReleaseRegistrationRequest(ctx context.Context, id string) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE registration_requests
+		SET status = 'pending', user_role = NULL, reviewed_by = NULL, reviewed_at = NULL, updated_at = NOW()
+		WHERE id = $1
+	`, id)
+	return err
+}
+
+// UserExists reports whether a user has the email
+func (s *TenantStore) UserExists(ctx context.Context, email string) (bool, error) {
+	var exists bool
+	err := s.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE lower(email) = lower($1))`, email).Scan(&exists)
+	return exists, err
+}
+
+// CreateRegisteredUser creates the user of an approved registration request
+// with the password it was submitted with. It returns an empty ID if the
+// email is taken.
+func (s *TenantStore) CreateRegisteredUser(ctx context.Context, r *domain.RegistrationRequest, role, createdBy, createdByEmail string) (string, error) {
+	var id string
+	now := time.Now()
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO users (email, password_hash, name, role, is_active, metadata, created_by, created_by_email, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, true, jsonb_build_object('organization', $5::text, 'registration_id', $6::text), $7, $8, $9, $9)
+		ON CONFLICT (email) DO NOTHING
+		RETURNING id
+	`, r.AdminEmail, r.PasswordHash, r.AdminName, role, r.OrganizationName, r.ID,
+		nullString(createdBy), nullString(createdByEmail), now).Scan(&id)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return id, err
+}
+
+// ListAdminEmails returns the emails of active admins
+func (s *TenantStore) ListAdminEmails(ctx context.Context) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT email FROM users WHERE role = 'admin' AND is_active = true ORDER BY email`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var emails []string
+	for rows.Next() {
+		var email string
+		if err := rows.Scan(&email); err != nil {
+			return nil, err
+		}
+		emails = append(emails, email)
+	}
+	return emails, rows.Err()
+}
@@ -0,0 +1,248 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lib/pq"
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+)
+
+// replicaLagQuery returns how far a replica is behind its primary in
+// seconds. A replica that has replayed everything it received is current
+// even when the primary has been idle for a while.
+const replicaLagQuery = `
+	SELECT CASE
+		WHEN NOT pg_is_in_recovery() OR pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+		ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+	END
+`
+
+// ReplicaSet routes read-only analytics queries to streaming replicas. A
+// replica is used while it answers health checks and is no further behind
+// the primary than max_lag; otherwise, and when a query on it fails, reads
+// fall back to the primary.
+type ReplicaSet struct {
+	primary  *DB
+	replicas []*replica
+	maxLag   time.Duration
+	interval time.Duration
+	next     atomic.Uint32
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+type replica struct {
+	name    string // Position in database.replicas.dsns, as DSNs hold credentials
+	db      *sql.DB
+	usable  atomic.Bool
+	lag     atomic.Int64 // Nanoseconds behind the primary at the last check
+	lastErr atomic.Pointer[string]
+}
+
+// NewReplicaSet opens the replicas in cfg. Replicas that can't be reached
+// yet are retried by the health checks, so they never block startup.
+func NewReplicaSet(primary *DB, cfg *config.DatabaseConfig) (*ReplicaSet, error) {
+	rs := &ReplicaSet{
+		primary:  primary,
+		maxLag:   cfg.Replicas.MaxLag,
+		interval: cfg.Replicas.CheckInterval,
+		stop:     make(chan struct{}),
+	}
+	for i, dsn := range cfg.Replicas.DSNs {
+		db, err := sql.Open("postgres", dsn)
+		if err != nil {
+			rs.closeReplicas()
+			return nil, fmt.Errorf("open replica %d: %w", i+1, err)
+		}
+		db.SetMaxOpenConns(cfg.MaxConns)
+		db.SetMaxIdleConns(cfg.MaxIdle)
+		db.SetConnMaxLifetime(cfg.ConnMaxAge)
+		rs.replicas = append(rs.replicas, &replica{name: fmt.Sprintf("replica-%d", i+1), db: db})
+	}
+	return rs, nil
+}
+
+// Start checks the replicas now and then every check_interval until Close
+func (rs *ReplicaSet) Start() {
+	rs.checkAll()
+	rs.wg.Add(1)
+	go func() {
+		defer rs.wg.Done()
+		ticker := time.NewTicker(rs.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-rs.stop:
+				return
+			case <-ticker.C:
+				rs.checkAll()
+			}
+		}
+	}()
+}
+
+// Close stops the health checks and closes the replica connections
+func (rs *ReplicaSet) Close() error {
+	close(rs.stop)
+	rs.wg.Wait()
+	return rs.closeReplicas()
+}
+
+func (rs *ReplicaSet) closeReplicas() error {
+	var errs []error
+	for _, r := range rs.replicas {
+		errs = append(errs, r.db.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// States returns the health and lag of each replica as of the last check
+func (rs *ReplicaSet) States() []domain.ReplicaState {
+	states := make([]domain.ReplicaState, len(rs.replicas))
+	for i, r := range rs.replicas {
+		states[i] = domain.ReplicaState{
+			Name:   r.name,
+			Usable: r.usable.Load(),
+			Lag:    time.Duration(r.lag.Load()),
+		}
+		if msg := r.lastErr.Load(); msg != nil {
+			states[i].Error = *msg
+		}
+	}
+	return states
+}
+
+func (rs *ReplicaSet) checkAll() {
+	var wg sync.WaitGroup
+	for _, r := range rs.replicas {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rs.check(r)
+		}()
+	}
+	wg.Wait()
+}
+
+// check measures a replica's lag and updates whether it is used
+func (rs *ReplicaSet) check(r *replica) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var lagSeconds float64
+	if err := r.db.QueryRowContext(ctx, replicaLagQuery).Scan(&lagSeconds); err != nil {
+		rs.markDown(r, err)
+		return
+	}
+	lag := time.Duration(lagSeconds * float64(time.Second))
+	r.lag.Store(int64(lag))
+
+	if lag > rs.maxLag {
+		msg := fmt.Sprintf("%s behind the primary", lag.Round(time.Second))
+		r.lastErr.Store(&msg)
+		if r.usable.Swap(false) {
+			slog.Warn("Read replica lagging, analytics reads fall back to the primary", "replica", r.name, "lag", lag, "max_lag", rs.maxLag)
+		}
+		return
+	}
+	r.lastErr.Store(nil)
+	if !r.usable.Swap(true) {
+		slog.Info("Read replica in use", "replica", r.name, "lag", lag)
+	}
+}
+
+func (rs *ReplicaSet) markDown(r *replica, err error) {
+	msg := err.Error()
+	r.lastErr.Store(&msg)
+	if r.usable.Swap(false) {
+		slog.Warn("Read replica unavailable, analytics reads fall back to the primary", "replica", r.name, "error", err)
+	}
+}
+
+// pick returns the next usable replica, round robin, or nil when none is
+func (rs *ReplicaSet) pick() *replica {
+	n := uint32(len(rs.replicas))
+	start := rs.next.Add(1)
+	for i := range n {
+		if r := rs.replicas[(start+i)%n]; r.usable.Load() {
+			return r
+		}
+	}
+	return nil
+}
+
+// retryOnPrimary reports whether a query that failed on a replica should
+// run again on the primary. Connection failures also take the replica out
+// of use until the next health check passes. Errors the primary would
+// return too, such as a bad query, are not retried.
+func (rs *ReplicaSet) retryOnPrimary(ctx context.Context, r *replica, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		rs.markDown(r, err)
+		return true
+	}
+	switch pqErr.Code.Class() {
+	case "08", "53", "57": // Connection exception, insufficient resources, operator intervention
+		rs.markDown(r, err)
+		return true
+	case "40": // Cancelled by a conflict with recovery
+		return true
+	}
+	return false
+}
+
+// analytics returns the querier for read-only analytics and statistics
+// queries: the read replicas when configured. A store scoped to a
+// transaction keeps reading in it.
+func (s *TenantStore) analytics() Querier {
+	if s.tx != nil {
+		return s.db
+	}
+	return s.pool.reader()
+}
+
+// reader returns a querier that reads from the replicas, falling back to
+// the primary. Statements that write always run on the primary.
+func (rs *ReplicaSet) reader() Querier {
+	return replicaReader{rs}
+}
+
+type replicaReader struct {
+	rs *ReplicaSet
+}
+
+func (q replicaReader) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return q.rs.primary.ExecContext(ctx, query, args...)
+}
+
+func (q replicaReader) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	if r := q.rs.pick(); r != nil {
+		rows, err := r.db.QueryContext(ctx, query, args...)
+		if err == nil || !q.rs.retryOnPrimary(ctx, r, err) {
+			return rows, err
+		}
+	}
+	return q.rs.primary.QueryContext(ctx, query, args...)
+}
+
+func (q replicaReader) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	if r := q.rs.pick(); r != nil {
+		row := r.db.QueryRowContext(ctx, query, args...)
+		if err := row.Err(); err == nil || !q.rs.retryOnPrimary(ctx, r, err) {
+			return row
+		}
+	}
+	return q.rs.primary.QueryRowContext(ctx, query, args...)
+}
@@ -173,7 +173,7 @@ func (a *UsageRepositoryAdapter) Record(ctx context.Context, record *domain.Usag
 
 // GetStats retrieves usage statistics
 func (a *UsageRepositoryAdapter) GetStats(ctx context.Context, tenantID string, startTime, endTime time.Time, granularity string) (*domain.UsageStats, error) {
-	return a.store.tenantStore.GetUsageStats(ctx, startTime, endTime)
+	return a.store.tenantStore.GetUsageStats(ctx, startTime, endTime, "")
 }
 
 // GetTenantQuotas retrieves tenant quotas (not implemented)
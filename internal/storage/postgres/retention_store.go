@@ -0,0 +1,177 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"modelgate/internal/domain"
+
+	"github.com/lib/pq"
+)
+
+// ============================================================================
+// Data retention (soft-delete, purge and policy overrides)
+// ============================================================================
+
+// retentionTimeColumns maps each retention-managed table to the column that
+// determines a row's age. Table names are only ever taken from this map.
+var retentionTimeColumns = map[string]string{
+	domain.RetentionTableUsageRecords: "created_at",
+	domain.RetentionTableAuditLogs:    "timestamp",
+}
+
+func retentionTimeColumn(table string) (string, error) {
+	column, ok := retentionTimeColumns[table]
+	if !ok {
+		return "", fmt.Errorf("table %q does not support retention", table)
+	}
+	return column, nil
+}
+
+// ListExpiredRows returns up to limit rows older than cutoff that are not yet soft-deleted, oldest first.
+// Each row is serialized with row_to_json so it can be archived as-is.
+func (s *TenantStore) ListExpiredRows(ctx context.Context, table string, cutoff time.Time, limit int) ([]domain.ExpiredRow, error) {
+	column, err := retentionTimeColumn(table)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(`
+		SELECT t.id, row_to_json(t)
+		FROM %s t
+		WHERE t.%s < $1 AND t.deleted_at IS NULL
+		ORDER BY t.%s
+		LIMIT $2
+	`, table, column, column)
+	rows, err := s.db.QueryContext(ctx, query, cutoff, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var expired []domain.ExpiredRow
+	for rows.Next() {
+		var row domain.ExpiredRow
+		if err := rows.Scan(&row.ID, &row.Data); err != nil {
+			return nil, err
+		}
+		expired = append(expired, row)
+	}
+	return expired, rows.Err()
+}
+
+// SoftDeleteRows marks rows as deleted. Soft-deleted rows are hidden from queries.
+func (s *TenantStore) SoftDeleteRows(ctx context.Context, table string, ids []string) (int64, error) {
+	if _, err := retentionTimeColumn(table); err != nil {
+		return 0, err
+	}
+
+	query := fmt.Sprintf(`UPDATE %s SET deleted_at = NOW() WHERE id = ANY($1::uuid[]) AND deleted_at IS NULL`, table)
+	result, err := s.db.ExecContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// PurgeSoftDeleted permanently deletes up to limit rows that were soft-deleted before the given time
+func (s *TenantStore) PurgeSoftDeleted(ctx context.Context, table string, before time.Time, limit int) (int64, error) {
+	if _, err := retentionTimeColumn(table); err != nil {
+		return 0, err
+	}
+
+	query := fmt.Sprintf(`
+		DELETE FROM %s WHERE id IN (
+			SELECT id FROM %s WHERE deleted_at IS NOT NULL AND deleted_at < $1 LIMIT $2
+		)
+	`, table, table)
+	result, err := s.db.ExecContext(ctx, query, before, limit)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// ListRetentionOverrides returns the stored retention state for every table that has one
+func (s *TenantStore) ListRetentionOverrides(ctx context.Context) (map[string]*domain.RetentionOverride, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT table_name, retention_days, archive_before_delete, enabled,
+			last_run_at, last_archived_count, last_deleted_count, updated_at
+		FROM retention_policies
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	overrides := make(map[string]*domain.RetentionOverride)
+	for rows.Next() {
+		var o domain.RetentionOverride
+		var retentionDays sql.NullInt64
+		var archive, enabled sql.NullBool
+		var lastRunAt sql.NullTime
+		if err := rows.Scan(&o.Table, &retentionDays, &archive, &enabled,
+			&lastRunAt, &o.LastArchivedCount, &o.LastDeletedCount, &o.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if retentionDays.Valid {
+			days := int(retentionDays.Int64)
+			o.RetentionDays = &days
+		}
+		if archive.Valid {
+			o.ArchiveBeforeDelete = &archive.Bool
+		}
+		if enabled.Valid {
+			o.Enabled = &enabled.Bool
+		}
+		if lastRunAt.Valid {
+			o.LastRunAt = &lastRunAt.Time
+		}
+		overrides[o.Table] = &o
+	}
+	return overrides, rows.Err()
+}
+
+// SaveRetentionOverride stores the settings of an override. Nil fields fall back to the config defaults.
+func (s *TenantStore) SaveRetentionOverride(ctx context.Context, o *domain.RetentionOverride) error {
+	if _, err := retentionTimeColumn(o.Table); err != nil {
+		return err
+	}
+
+	var retentionDays sql.NullInt64
+	if o.RetentionDays != nil {
+		retentionDays = sql.NullInt64{Int64: int64(*o.RetentionDays), Valid: true}
+	}
+	var archive, enabled sql.NullBool
+	if o.ArchiveBeforeDelete != nil {
+		archive = sql.NullBool{Bool: *o.ArchiveBeforeDelete, Valid: true}
+	}
+	if o.Enabled != nil {
+		enabled = sql.NullBool{Bool: *o.Enabled, Valid: true}
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO retention_policies (table_name, retention_days, archive_before_delete, enabled)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (table_name) DO UPDATE SET
+			retention_days = EXCLUDED.retention_days,
+			archive_before_delete = EXCLUDED.archive_before_delete,
+			enabled = EXCLUDED.enabled
+	`, o.Table, retentionDays, archive, enabled)
+	return err
+}
+
+// RecordRetentionRun stores the result of a janitor run for a table
+func (s *TenantStore) RecordRetentionRun(ctx context.Context, table string, archived, deleted int64, at time.Time) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO retention_policies (table_name, last_run_at, last_archived_count, last_deleted_count)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (table_name) DO UPDATE SET
+			last_run_at = EXCLUDED.last_run_at,
+			last_archived_count = EXCLUDED.last_archived_count,
+			last_deleted_count = EXCLUDED.last_deleted_count
+	`, table, at, archived, deleted)
+	return err
+}
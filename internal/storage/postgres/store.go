@@ -8,7 +8,9 @@ import (
 	"time"
 
 	"modelgate/internal/config"
+	"modelgate/internal/crypto"
 	"modelgate/internal/domain"
+	"modelgate/internal/passwords"
 )
 
 // Store is the main PostgreSQL store that manages all storage operations
@@ -62,12 +64,27 @@ func (s *Store) TenantStore() *TenantStore {
 	return s.tenantStore
 }
 
+// SetEncryption sets the keyring used to encrypt credentials at rest
+func (s *Store) SetEncryption(keyring *crypto.Keyring) {
+	s.tenantStore.SetEncryption(keyring)
+}
+
+// SetPasswordPolicy sets the policy user passwords are hashed with and held to
+func (s *Store) SetPasswordPolicy(policy *passwords.Policy) {
+	s.tenantStore.SetPasswordPolicy(policy)
+}
+
 // GetTenantStore returns the tenant store (deprecated - use TenantStore() instead)
 // Kept for compatibility during migration
 func (s *Store) GetTenantStore(tenantSlug string) (*TenantStore, error) {
 	return s.tenantStore, nil
 }
 
+// InTx runs fn as one unit of work on the tenant store; see TenantStore.InTx
+func (s *Store) InTx(ctx context.Context, fn func(tx *TenantStore) error) error {
+	return s.tenantStore.InTx(ctx, fn)
+}
+
 // TenantRepository returns a repository adapter for tenant operations
 func (s *Store) TenantRepository() domain.TenantRepository {
 	return NewTenantRepositoryAdapter(s)
@@ -97,6 +114,11 @@ func (s *Store) ValidateUserPassword(ctx context.Context, email, password string
 	return s.tenantStore.ValidateUserPassword(ctx, email, password)
 }
 
+// ChangeUserPassword replaces a user's password after checking the current one
+func (s *Store) ChangeUserPassword(ctx context.Context, email, currentPassword, newPassword string) (*TenantUser, error) {
+	return s.tenantStore.ChangeUserPassword(ctx, email, currentPassword, newPassword)
+}
+
 // CreateSession creates a session for a user
 func (s *Store) CreateSession(ctx context.Context, userID string, duration time.Duration) (*TenantSession, string, error) {
 	return s.tenantStore.CreateSession(ctx, userID, duration)
@@ -271,14 +293,14 @@ func (s *Store) RecordUsage(ctx context.Context, record *domain.UsageRecord) err
 	return s.tenantStore.RecordUsage(ctx, record)
 }
 
-// GetUsageStats gets usage statistics
-func (s *Store) GetUsageStats(ctx context.Context, startTime, endTime time.Time) (*domain.UsageStats, error) {
-	return s.tenantStore.GetUsageStats(ctx, startTime, endTime)
+// GetUsageStats gets usage statistics, optionally for one project
+func (s *Store) GetUsageStats(ctx context.Context, startTime, endTime time.Time, projectID string) (*domain.UsageStats, error) {
+	return s.tenantStore.GetUsageStats(ctx, startTime, endTime, projectID)
 }
 
 // ListUsageRecords lists usage records with filters
-func (s *Store) ListUsageRecords(ctx context.Context, startTime, endTime time.Time, model, status, apiKeyID string, limit int) ([]*domain.UsageRecord, error) {
-	return s.tenantStore.ListUsageRecords(ctx, startTime, endTime, model, status, apiKeyID, limit)
+func (s *Store) ListUsageRecords(ctx context.Context, startTime, endTime time.Time, model, status, apiKeyID, projectID, userID string, limit int) ([]*domain.UsageRecord, error) {
+	return s.tenantStore.ListUsageRecords(ctx, startTime, endTime, model, status, apiKeyID, projectID, userID, limit)
 }
 
 // GetUsageRecord gets a single usage record
@@ -286,24 +308,34 @@ func (s *Store) GetUsageRecord(ctx context.Context, id string) (*domain.UsageRec
 	return s.tenantStore.GetUsageRecord(ctx, id)
 }
 
+// OpenCapturedContent decrypts the captured content of a usage record
+func (s *Store) OpenCapturedContent(ctx context.Context, record *domain.UsageRecord) error {
+	return s.tenantStore.OpenCapturedContent(ctx, record)
+}
+
 // GetUsageStatsByModel gets usage statistics grouped by model
-func (s *Store) GetUsageStatsByModel(ctx context.Context, startTime, endTime time.Time) (map[string]*domain.ModelUsageStats, error) {
-	return s.tenantStore.GetUsageStatsByModel(ctx, startTime, endTime)
+func (s *Store) GetUsageStatsByModel(ctx context.Context, startTime, endTime time.Time, projectID string) (map[string]*domain.ModelUsageStats, error) {
+	return s.tenantStore.GetUsageStatsByModel(ctx, startTime, endTime, projectID)
+}
+
+// GetModelPerformance gets latency and streaming performance grouped by model and provider
+func (s *Store) GetModelPerformance(ctx context.Context, startTime, endTime time.Time, projectID string) ([]*domain.ModelPerformance, error) {
+	return s.tenantStore.GetModelPerformance(ctx, startTime, endTime, projectID)
 }
 
 // GetUsageStatsByProvider gets usage statistics grouped by provider
-func (s *Store) GetUsageStatsByProvider(ctx context.Context, startTime, endTime time.Time) (map[string]*domain.ProviderUsageStats, error) {
-	return s.tenantStore.GetUsageStatsByProvider(ctx, startTime, endTime)
+func (s *Store) GetUsageStatsByProvider(ctx context.Context, startTime, endTime time.Time, projectID string) (map[string]*domain.ProviderUsageStats, error) {
+	return s.tenantStore.GetUsageStatsByProvider(ctx, startTime, endTime, projectID)
 }
 
 // GetUsageStatsByAPIKey gets usage statistics grouped by API key
-func (s *Store) GetUsageStatsByAPIKey(ctx context.Context, startTime, endTime time.Time) (map[string]*domain.APIKeyUsageStats, error) {
-	return s.tenantStore.GetUsageStatsByAPIKey(ctx, startTime, endTime)
+func (s *Store) GetUsageStatsByAPIKey(ctx context.Context, startTime, endTime time.Time, projectID string) (map[string]*domain.APIKeyUsageStats, error) {
+	return s.tenantStore.GetUsageStatsByAPIKey(ctx, startTime, endTime, projectID)
 }
 
 // GetUsageTimeSeries gets usage over time for charts
-func (s *Store) GetUsageTimeSeries(ctx context.Context, startTime, endTime time.Time, interval string) ([]*domain.UsageTimePoint, error) {
-	return s.tenantStore.GetUsageTimeSeries(ctx, startTime, endTime, interval)
+func (s *Store) GetUsageTimeSeries(ctx context.Context, startTime, endTime time.Time, interval, projectID string) ([]*domain.UsageTimePoint, error) {
+	return s.tenantStore.GetUsageTimeSeries(ctx, startTime, endTime, interval, projectID)
 }
 
 // =============================================================================
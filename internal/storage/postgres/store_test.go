@@ -0,0 +1,52 @@
+package postgres
+
+import (
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"modelgate/internal/config"
+)
+
+var (
+	testDBOnce sync.Once
+	testDB     *DB
+	testDBErr  error
+)
+
+// testStore returns a tenant store on the PostgreSQL database named by the
+// DATABASE_* variables CI sets, with the migrations applied. Tests that use
+// it are skipped without a database.
+func testStore(t *testing.T) *TenantStore {
+	t.Helper()
+	if os.Getenv("DATABASE_HOST") == "" {
+		t.Skip("DATABASE_HOST is not set; skipping PostgreSQL store test")
+	}
+
+	testDBOnce.Do(func() {
+		port, _ := strconv.Atoi(os.Getenv("DATABASE_PORT"))
+		if port == 0 {
+			port = 5432
+		}
+		cfg := &config.DatabaseConfig{
+			Host:       os.Getenv("DATABASE_HOST"),
+			Port:       port,
+			User:       os.Getenv("DATABASE_USER"),
+			Password:   os.Getenv("DATABASE_PASSWORD"),
+			Database:   os.Getenv("DATABASE_NAME"),
+			SSLMode:    "disable",
+			MaxConns:   10,
+			MaxIdle:    2,
+			ConnMaxAge: time.Minute,
+		}
+		if testDB, testDBErr = NewDB(cfg, cfg.GetDSN()); testDBErr == nil {
+			testDBErr = RunMigrations(testDB.DB, "../../../migrations")
+		}
+	})
+	if testDBErr != nil {
+		t.Fatalf("test database: %v", testDBErr)
+	}
+	return NewTenantStore(testDB, "default")
+}
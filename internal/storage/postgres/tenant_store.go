@@ -10,52 +10,93 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"modelgate/internal/crypto"
 	"modelgate/internal/domain"
+	"modelgate/internal/passwords"
 
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 )
 
 // TenantStore handles tenant database operations
 type TenantStore struct {
-	db         *DB
+	// db runs statements: the pool, or tx for a store scoped to a transaction
+	db         Querier
+	pool       *DB
+	tx         *sql.Tx
 	tenantSlug string
+
+	// encryption seals MCP server auth configs; nil stores them as plain JSON
+	encryption *crypto.Keyring
+
+	// passwords hashes user passwords and enforces [passwords]; nil uses the defaults
+	passwords *passwords.Policy
+
+	// shared holds the caches a store shares with the copies scoped to its transactions
+	shared *tenantCache
+}
+
+// tenantCache holds lazily loaded tenant state
+type tenantCache struct {
+	// Cipher for the tenant data key that seals captured prompts and responses
+	contentMu     sync.Mutex
+	contentCipher *crypto.ContentCipher
+
+	// Dimensions of the pgvector tool embedding column, 0 without pgvector,
+	// and when they were last read (unix nanoseconds, 0 to read them again)
+	toolVectorMu        sync.Mutex
+	toolVectorDims      atomic.Int32
+	toolVectorCheckedAt atomic.Int64
 }
 
 // NewTenantStore creates a new tenant store
 func NewTenantStore(db *DB, tenantSlug string) *TenantStore {
-	return &TenantStore{db: db, tenantSlug: tenantSlug}
+	return &TenantStore{db: db, pool: db, tenantSlug: tenantSlug, shared: &tenantCache{}}
 }
 
-// DB returns the underlying database connection
-func (s *TenantStore) DB() *DB {
+// DB returns the connection the store runs its statements on: the
+// transaction for a store scoped to one, otherwise the pool
+func (s *TenantStore) DB() Querier {
 	return s.db
 }
 
+// Pool returns the underlying database connection pool
+func (s *TenantStore) Pool() *DB {
+	return s.pool
+}
+
 // =============================================================================
 // User Operations
 // =============================================================================
 
 // TenantUser represents a user within a tenant
 type TenantUser struct {
-	ID             string         `json:"id"`
-	Email          string         `json:"email"`
-	Name           string         `json:"name"`
-	Role           string         `json:"role"`
-	IsActive       bool           `json:"is_active"`
-	LastLoginAt    *time.Time     `json:"last_login_at,omitempty"`
-	Metadata       map[string]any `json:"metadata,omitempty"`
-	CreatedBy      string         `json:"created_by,omitempty"`
-	CreatedByEmail string         `json:"created_by_email,omitempty"`
-	CreatedAt      time.Time      `json:"created_at"`
-	UpdatedAt      time.Time      `json:"updated_at"`
+	ID               string         `json:"id"`
+	Email            string         `json:"email"`
+	Name             string         `json:"name"`
+	Role             string         `json:"role"`
+	IsActive         bool           `json:"is_active"`
+	APIRoleID        string         `json:"api_role_id,omitempty"`       // RBAC role of the user's personal API keys
+	DashboardRoleID  string         `json:"dashboard_role_id,omitempty"` // Role whose permissions apply in the admin API
+	TwoFactorEnabled bool           `json:"two_factor_enabled"`
+	LastLoginAt      *time.Time     `json:"last_login_at,omitempty"`
+	Metadata         map[string]any `json:"metadata,omitempty"`
+	CreatedBy        string         `json:"created_by,omitempty"`
+	CreatedByEmail   string         `json:"created_by_email,omitempty"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
 }
 
 // CreateUser creates a new tenant user
 func (s *TenantStore) CreateUser(ctx context.Context, email, password, name, role, createdBy, createdByEmail string) (*TenantUser, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	policy := s.passwordPolicy()
+	if err := policy.Check(password, email, name); err != nil {
+		return nil, err
+	}
+	hashedPassword, err := policy.Hash(password)
 	if err != nil {
 		return nil, fmt.Errorf("failed to hash password: %w", err)
 	}
@@ -71,7 +112,7 @@ func (s *TenantStore) CreateUser(ctx context.Context, email, password, name, rol
 
 	var user TenantUser
 	var createdByVal, createdByEmailVal sql.NullString
-	err = s.db.QueryRowContext(ctx, query, id, email, string(hashedPassword), name, role,
+	err = s.db.QueryRowContext(ctx, query, id, email, hashedPassword, name, role,
 		sql.NullString{String: createdBy, Valid: createdBy != ""},
 		sql.NullString{String: createdByEmail, Valid: createdByEmail != ""},
 		now, now).Scan(
@@ -89,17 +130,19 @@ func (s *TenantStore) CreateUser(ctx context.Context, email, password, name, rol
 // GetUser gets a user by ID
 func (s *TenantStore) GetUser(ctx context.Context, id string) (*TenantUser, error) {
 	query := `
-		SELECT id, email, name, role, is_active, last_login_at, metadata, created_by, created_by_email, created_at, updated_at
+		SELECT id, email, name, role, is_active, last_login_at, metadata, created_by, created_by_email, created_at, updated_at, api_role_id,
+		       dashboard_role_id, EXISTS (SELECT 1 FROM user_two_factor t WHERE t.user_id = users.id AND t.enabled)
 		FROM users WHERE id = $1
 	`
 
 	var user TenantUser
 	var metadataJSON []byte
-	var createdBy, createdByEmail sql.NullString
+	var createdBy, createdByEmail, apiRoleID, dashboardRoleID sql.NullString
 
 	err := s.db.QueryRowContext(ctx, query, id).Scan(
 		&user.ID, &user.Email, &user.Name, &user.Role, &user.IsActive,
-		&user.LastLoginAt, &metadataJSON, &createdBy, &createdByEmail, &user.CreatedAt, &user.UpdatedAt)
+		&user.LastLoginAt, &metadataJSON, &createdBy, &createdByEmail, &user.CreatedAt, &user.UpdatedAt, &apiRoleID,
+		&dashboardRoleID, &user.TwoFactorEnabled)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -111,11 +154,14 @@ func (s *TenantStore) GetUser(ctx context.Context, id string) (*TenantUser, erro
 	json.Unmarshal(metadataJSON, &user.Metadata)
 	user.CreatedBy = createdBy.String
 	user.CreatedByEmail = createdByEmail.String
+	user.APIRoleID = apiRoleID.String
+	user.DashboardRoleID = dashboardRoleID.String
 	return &user, nil
 }
 
-// UpdateUser updates a user
-func (s *TenantStore) UpdateUser(ctx context.Context, id string, name *string, role *string, isActive *bool) (*TenantUser, error) {
+// UpdateUser updates a user. An empty apiRoleID or dashboardRoleID clears
+// that role. Deactivating a user revokes their personal API keys.
+func (s *TenantStore) UpdateUser(ctx context.Context, id string, name *string, role *string, isActive *bool, apiRoleID, dashboardRoleID *string) (*TenantUser, error) {
 	// Build dynamic update query
 	updates := []string{}
 	args := []interface{}{}
@@ -136,6 +182,16 @@ func (s *TenantStore) UpdateUser(ctx context.Context, id string, name *string, r
 		args = append(args, *isActive)
 		argIdx++
 	}
+	if apiRoleID != nil {
+		updates = append(updates, fmt.Sprintf("api_role_id = $%d", argIdx))
+		args = append(args, nullString(*apiRoleID))
+		argIdx++
+	}
+	if dashboardRoleID != nil {
+		updates = append(updates, fmt.Sprintf("dashboard_role_id = $%d", argIdx))
+		args = append(args, nullString(*dashboardRoleID))
+		argIdx++
+	}
 
 	if len(updates) == 0 {
 		return s.GetUser(ctx, id)
@@ -148,10 +204,23 @@ func (s *TenantStore) UpdateUser(ctx context.Context, id string, name *string, r
 	args = append(args, id)
 	query := fmt.Sprintf(`UPDATE users SET %s WHERE id = $%d`, strings.Join(updates, ", "), argIdx)
 
-	_, err := s.db.ExecContext(ctx, query, args...)
+	tx, err := s.beginTx(ctx)
 	if err != nil {
 		return nil, err
 	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return nil, err
+	}
+	if isActive != nil && !*isActive {
+		if err := revokeUserAPIKeys(ctx, tx, id, "owner deactivated"); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
 
 	return s.GetUser(ctx, id)
 }
@@ -192,33 +261,11 @@ func (s *TenantStore) GetUserByEmail(ctx context.Context, email string) (*Tenant
 	return &user, passwordHash, nil
 }
 
-// ValidateUserPassword validates a user's password
-func (s *TenantStore) ValidateUserPassword(ctx context.Context, email, password string) (*TenantUser, error) {
-	user, passwordHash, err := s.GetUserByEmail(ctx, email)
-	if err != nil {
-		return nil, err
-	}
-	if user == nil {
-		return nil, fmt.Errorf("user not found")
-	}
-	if !user.IsActive {
-		return nil, fmt.Errorf("user is not active")
-	}
-
-	if err := bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(password)); err != nil {
-		return nil, fmt.Errorf("invalid password")
-	}
-
-	// Update last login
-	s.db.ExecContext(ctx, "UPDATE users SET last_login_at = $1 WHERE id = $2", time.Now(), user.ID)
-
-	return user, nil
-}
-
 // ListUsers lists all users
 func (s *TenantStore) ListUsers(ctx context.Context) ([]*TenantUser, error) {
 	query := `
-		SELECT id, email, name, role, is_active, last_login_at, metadata, created_by, created_by_email, created_at, updated_at
+		SELECT id, email, name, role, is_active, last_login_at, metadata, created_by, created_by_email, created_at, updated_at, api_role_id,
+		       dashboard_role_id, EXISTS (SELECT 1 FROM user_two_factor t WHERE t.user_id = users.id AND t.enabled)
 		FROM users ORDER BY created_at DESC
 	`
 
@@ -232,10 +279,11 @@ func (s *TenantStore) ListUsers(ctx context.Context) ([]*TenantUser, error) {
 	for rows.Next() {
 		var user TenantUser
 		var metadataJSON []byte
-		var createdBy, createdByEmail sql.NullString
+		var createdBy, createdByEmail, apiRoleID, dashboardRoleID sql.NullString
 
 		err := rows.Scan(&user.ID, &user.Email, &user.Name, &user.Role, &user.IsActive,
-			&user.LastLoginAt, &metadataJSON, &createdBy, &createdByEmail, &user.CreatedAt, &user.UpdatedAt)
+			&user.LastLoginAt, &metadataJSON, &createdBy, &createdByEmail, &user.CreatedAt, &user.UpdatedAt, &apiRoleID,
+			&dashboardRoleID, &user.TwoFactorEnabled)
 		if err != nil {
 			return nil, err
 		}
@@ -243,6 +291,8 @@ func (s *TenantStore) ListUsers(ctx context.Context) ([]*TenantUser, error) {
 		json.Unmarshal(metadataJSON, &user.Metadata)
 		user.CreatedBy = createdBy.String
 		user.CreatedByEmail = createdByEmail.String
+		user.APIRoleID = apiRoleID.String
+		user.DashboardRoleID = dashboardRoleID.String
 		users = append(users, &user)
 	}
 
@@ -296,7 +346,8 @@ func (s *TenantStore) GetSessionByToken(ctx context.Context, token string) (*Ten
 
 	query := `
 		SELECT s.id, s.user_id, s.expires_at, s.created_at,
-		       u.id, u.email, u.name, u.role, u.is_active, u.last_login_at, u.metadata, u.created_at, u.updated_at
+		       u.id, u.email, u.name, u.role, u.is_active, u.last_login_at, u.metadata, u.created_at, u.updated_at,
+		       u.dashboard_role_id
 		FROM sessions s
 		JOIN users u ON s.user_id = u.id
 		WHERE s.token_hash = $1 AND s.expires_at > $2 AND u.is_active = true
@@ -305,11 +356,12 @@ func (s *TenantStore) GetSessionByToken(ctx context.Context, token string) (*Ten
 	var session TenantSession
 	var user TenantUser
 	var metadataJSON []byte
+	var dashboardRoleID sql.NullString
 
 	err := s.db.QueryRowContext(ctx, query, tokenHash, time.Now()).Scan(
 		&session.ID, &session.UserID, &session.ExpiresAt, &session.CreatedAt,
 		&user.ID, &user.Email, &user.Name, &user.Role, &user.IsActive,
-		&user.LastLoginAt, &metadataJSON, &user.CreatedAt, &user.UpdatedAt)
+		&user.LastLoginAt, &metadataJSON, &user.CreatedAt, &user.UpdatedAt, &dashboardRoleID)
 
 	if err == sql.ErrNoRows {
 		return nil, nil, nil
@@ -319,6 +371,7 @@ func (s *TenantStore) GetSessionByToken(ctx context.Context, token string) (*Ten
 	}
 
 	json.Unmarshal(metadataJSON, &user.Metadata)
+	user.DashboardRoleID = dashboardRoleID.String
 	return &session, &user, nil
 }
 
@@ -449,7 +502,7 @@ func (s *TenantStore) DeleteRole(ctx context.Context, id string) error {
 // ListRoles lists all roles
 func (s *TenantStore) ListRoles(ctx context.Context) ([]*domain.Role, error) {
 	query := `
-		SELECT id, name, description, permissions, is_default, created_by, created_by_email, created_at, updated_at
+		SELECT id, name, description, permissions, is_default, COALESCE(is_system, false), created_by, created_by_email, created_at, updated_at
 		FROM roles ORDER BY name
 	`
 
@@ -466,7 +519,7 @@ func (s *TenantStore) ListRoles(ctx context.Context) ([]*domain.Role, error) {
 		var createdBy, createdByEmail sql.NullString
 
 		err := rows.Scan(&role.ID, &role.Name, &role.Description, &permissionsJSON,
-			&role.IsDefault, &createdBy, &createdByEmail, &role.CreatedAt, &role.UpdatedAt)
+			&role.IsDefault, &role.IsSystem, &createdBy, &createdByEmail, &role.CreatedAt, &role.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -480,6 +533,15 @@ func (s *TenantStore) ListRoles(ctx context.Context) ([]*domain.Role, error) {
 	return roles, nil
 }
 
+// SemanticCacheEnabled reports whether any role has the semantic cache enabled
+func (s *TenantStore) SemanticCacheEnabled(ctx context.Context) (bool, error) {
+	var enabled bool
+	err := s.db.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM role_policies WHERE (caching_policy->>'enabled')::boolean)
+	`).Scan(&enabled)
+	return enabled, err
+}
+
 // GetDefaultRole gets the default role
 func (s *TenantStore) GetDefaultRole(ctx context.Context) (*domain.Role, error) {
 	query := `
@@ -527,6 +589,11 @@ func (s *TenantStore) CreateRolePolicy(ctx context.Context, policy *domain.RoleP
 	routingJSON, _ := json.Marshal(policy.RoutingPolicy)
 	resilienceJSON, _ := json.Marshal(policy.ResiliencePolicy)
 	budgetJSON, _ := json.Marshal(policy.BudgetPolicy)
+	concurrencyJSON, _ := json.Marshal(policy.ConcurrencyPolicy)
+	networkJSON, _ := json.Marshal(policy.NetworkPolicy)
+	fileJSON, _ := json.Marshal(policy.FilePolicy)
+	generationJSON, _ := json.Marshal(policy.GenerationPolicy)
+	outputTransformJSON, _ := json.Marshal(policy.OutputTransform)
 
 	now := time.Now()
 	policy.CreatedAt = now
@@ -536,9 +603,10 @@ func (s *TenantStore) CreateRolePolicy(ctx context.Context, policy *domain.RoleP
 		INSERT INTO role_policies (
 			id, role_id, prompt_policies, tool_policies, rate_limit_policy,
 			model_restrictions, mcp_policies, caching_policy, routing_policy,
-			resilience_policy, budget_policy, created_at, updated_at
+			resilience_policy, budget_policy, concurrency_policy, network_policy, file_policy, generation_policy,
+			output_transform_policy, created_at, updated_at
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
 		ON CONFLICT (role_id) DO UPDATE SET
 			prompt_policies = EXCLUDED.prompt_policies,
 			tool_policies = EXCLUDED.tool_policies,
@@ -549,12 +617,18 @@ func (s *TenantStore) CreateRolePolicy(ctx context.Context, policy *domain.RoleP
 			routing_policy = EXCLUDED.routing_policy,
 			resilience_policy = EXCLUDED.resilience_policy,
 			budget_policy = EXCLUDED.budget_policy,
+			concurrency_policy = EXCLUDED.concurrency_policy,
+			network_policy = EXCLUDED.network_policy,
+			file_policy = EXCLUDED.file_policy,
+			generation_policy = EXCLUDED.generation_policy,
+			output_transform_policy = EXCLUDED.output_transform_policy,
 			updated_at = EXCLUDED.updated_at
 	`
 
 	_, err := s.db.ExecContext(ctx, query, policy.ID, policy.RoleID,
 		promptJSON, toolJSON, rateLimitJSON, modelJSON, mcpJSON,
-		cachingJSON, routingJSON, resilienceJSON, budgetJSON, now, now)
+		cachingJSON, routingJSON, resilienceJSON, budgetJSON, concurrencyJSON, networkJSON, fileJSON, generationJSON,
+		outputTransformJSON, now, now)
 	return err
 }
 
@@ -567,18 +641,24 @@ func (s *TenantStore) GetRolePolicy(ctx context.Context, roleID string) (*domain
 		       COALESCE(routing_policy, '{}'),
 		       COALESCE(resilience_policy, '{}'),
 		       COALESCE(budget_policy, '{}'),
+		       COALESCE(concurrency_policy, '{}'),
+		       COALESCE(network_policy, '{}'),
+		       COALESCE(file_policy, '{}'),
+		       COALESCE(generation_policy, '{}'),
+		       COALESCE(output_transform_policy, '{}'),
 		       created_at, updated_at
 		FROM role_policies WHERE role_id = $1
 	`
 
 	var policy domain.RolePolicy
 	var promptJSON, toolJSON, rateLimitJSON, modelJSON, mcpJSON []byte
-	var cachingJSON, routingJSON, resilienceJSON, budgetJSON []byte
+	var cachingJSON, routingJSON, resilienceJSON, budgetJSON, concurrencyJSON, networkJSON, fileJSON, generationJSON []byte
+	var outputTransformJSON []byte
 
 	err := s.db.QueryRowContext(ctx, query, roleID).Scan(
 		&policy.ID, &policy.RoleID, &promptJSON, &toolJSON, &rateLimitJSON, &modelJSON, &mcpJSON,
-		&cachingJSON, &routingJSON, &resilienceJSON, &budgetJSON,
-		&policy.CreatedAt, &policy.UpdatedAt)
+		&cachingJSON, &routingJSON, &resilienceJSON, &budgetJSON, &concurrencyJSON, &networkJSON, &fileJSON, &generationJSON,
+		&outputTransformJSON, &policy.CreatedAt, &policy.UpdatedAt)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -599,6 +679,11 @@ func (s *TenantStore) GetRolePolicy(ctx context.Context, roleID string) (*domain
 	json.Unmarshal(routingJSON, &policy.RoutingPolicy)
 	json.Unmarshal(resilienceJSON, &policy.ResiliencePolicy)
 	json.Unmarshal(budgetJSON, &policy.BudgetPolicy)
+	json.Unmarshal(concurrencyJSON, &policy.ConcurrencyPolicy)
+	json.Unmarshal(networkJSON, &policy.NetworkPolicy)
+	json.Unmarshal(fileJSON, &policy.FilePolicy)
+	json.Unmarshal(generationJSON, &policy.GenerationPolicy)
+	json.Unmarshal(outputTransformJSON, &policy.OutputTransform)
 
 	return &policy, nil
 }
@@ -612,7 +697,7 @@ func (s *TenantStore) UpdateRolePolicy(ctx context.Context, policy *domain.RoleP
 // Group Operations
 // =============================================================================
 
-// CreateGroup creates a new group
+// CreateGroup creates a new group with its role associations in one transaction
 func (s *TenantStore) CreateGroup(ctx context.Context, group *domain.Group) error {
 	if group.ID == "" {
 		group.ID = uuid.New().String()
@@ -627,24 +712,28 @@ func (s *TenantStore) CreateGroup(ctx context.Context, group *domain.Group) erro
 		VALUES ($1, $2, $3, $4, $5, $6, $7)
 	`
 
-	_, err := s.db.ExecContext(ctx, query, group.ID, group.Name, group.Description,
-		sql.NullString{String: group.CreatedBy, Valid: group.CreatedBy != ""},
-		sql.NullString{String: group.CreatedByEmail, Valid: group.CreatedByEmail != ""},
-		now, now)
-	if err != nil {
-		return err
-	}
+	return s.InTx(ctx, func(tx *TenantStore) error {
+		_, err := tx.db.ExecContext(ctx, query, group.ID, group.Name, group.Description,
+			sql.NullString{String: group.CreatedBy, Valid: group.CreatedBy != ""},
+			sql.NullString{String: group.CreatedByEmail, Valid: group.CreatedByEmail != ""},
+			now, now)
+		if err != nil {
+			return err
+		}
+		return tx.insertGroupRoles(ctx, group.ID, group.RoleIDs)
+	})
+}
 
-	// Add role associations
-	for _, roleID := range group.RoleIDs {
+// insertGroupRoles associates roles with a group
+func (s *TenantStore) insertGroupRoles(ctx context.Context, groupID string, roleIDs []string) error {
+	for _, roleID := range roleIDs {
 		_, err := s.db.ExecContext(ctx,
 			"INSERT INTO group_roles (group_id, role_id) VALUES ($1, $2) ON CONFLICT DO NOTHING",
-			group.ID, roleID)
+			groupID, roleID)
 		if err != nil {
-			return err
+			return fmt.Errorf("add role %s to group: %w", roleID, err)
 		}
 	}
-
 	return nil
 }
 
@@ -723,23 +812,20 @@ func (s *TenantStore) ListGroups(ctx context.Context) ([]*domain.Group, error) {
 	return groups, nil
 }
 
-// UpdateGroup updates a group
+// UpdateGroup updates a group and replaces its role associations in one transaction
 func (s *TenantStore) UpdateGroup(ctx context.Context, group *domain.Group) error {
 	group.UpdatedAt = time.Now()
 
 	query := `UPDATE groups SET name = $2, description = $3, updated_at = $4 WHERE id = $1`
-	_, err := s.db.ExecContext(ctx, query, group.ID, group.Name, group.Description, group.UpdatedAt)
-	if err != nil {
-		return err
-	}
-
-	// Update role associations
-	s.db.ExecContext(ctx, "DELETE FROM group_roles WHERE group_id = $1", group.ID)
-	for _, roleID := range group.RoleIDs {
-		s.db.ExecContext(ctx, "INSERT INTO group_roles (group_id, role_id) VALUES ($1, $2)", group.ID, roleID)
-	}
-
-	return nil
+	return s.InTx(ctx, func(tx *TenantStore) error {
+		if _, err := tx.db.ExecContext(ctx, query, group.ID, group.Name, group.Description, group.UpdatedAt); err != nil {
+			return err
+		}
+		if _, err := tx.db.ExecContext(ctx, "DELETE FROM group_roles WHERE group_id = $1", group.ID); err != nil {
+			return err
+		}
+		return tx.insertGroupRoles(ctx, group.ID, group.RoleIDs)
+	})
 }
 
 // DeleteGroup deletes a group
@@ -784,12 +870,7 @@ func (s *TenantStore) GetGroupRoles(ctx context.Context, groupID string) ([]*dom
 
 // CreateAPIKey creates a new API key with role or group assignment
 func (s *TenantStore) CreateAPIKey(ctx context.Context, name string, roleID string, groupID string, scopes []string, expiresAt *time.Time) (*domain.APIKey, string, error) {
-	// Generate key
-	keyBytes := make([]byte, 32)
-	rand.Read(keyBytes)
-	fullKey := "mg_" + hex.EncodeToString(keyBytes)
-	keyPrefix := fullKey[:11]
-	keyHash := hashAPIKey(fullKey)
+	fullKey, keyPrefix, keyHash := generateAPIKey()
 
 	id := uuid.New().String()
 	now := time.Now()
@@ -830,25 +911,37 @@ func (s *TenantStore) CreateAPIKey(ctx context.Context, name string, roleID stri
 	return apiKey, fullKey, nil
 }
 
+// generateAPIKey returns a new API key with its display prefix and hash
+func generateAPIKey() (fullKey, keyPrefix, keyHash string) {
+	keyBytes := make([]byte, 32)
+	rand.Read(keyBytes)
+	fullKey = "mg_" + hex.EncodeToString(keyBytes)
+	return fullKey, fullKey[:11], hashAPIKey(fullKey)
+}
+
 // GetAPIKey gets an API key by ID
 func (s *TenantStore) GetAPIKey(ctx context.Context, id string) (*domain.APIKeyWithRole, error) {
 	query := `
-		SELECT k.id, k.name, k.key_prefix, k.key_hash, k.role_id, k.group_id, k.scopes, k.expires_at, k.last_used_at, k.is_revoked, k.created_at, k.updated_at,
-		       r.name as role_name, g.name as group_name
+		SELECT k.id, k.name, k.key_prefix, k.key_hash, COALESCE(k.role_id, u.api_role_id), k.group_id, k.scopes, k.expires_at, k.last_used_at, k.is_revoked, k.created_at, k.updated_at,
+		       COALESCE(k.allowed_cidrs, '[]'), r.name as role_name, g.name as group_name, k.project_id, p.name as project_name, k.budget,
+		       k.owner_user_id, u.email
 		FROM api_keys k
-		LEFT JOIN roles r ON k.role_id = r.id
+		LEFT JOIN users u ON k.owner_user_id = u.id
+		LEFT JOIN roles r ON COALESCE(k.role_id, u.api_role_id) = r.id
 		LEFT JOIN groups g ON k.group_id = g.id
+		LEFT JOIN projects p ON k.project_id = p.id
 		WHERE k.id = $1
 	`
 
 	var key domain.APIKeyWithRole
-	var scopesJSON []byte
-	var roleID, roleName, groupID, groupName sql.NullString
+	var scopesJSON, cidrsJSON, budgetJSON []byte
+	var roleID, roleName, groupID, groupName, projectID, projectName, ownerID, ownerEmail sql.NullString
 	var expiresAt, lastUsedAt sql.NullTime
 
 	err := s.db.QueryRowContext(ctx, query, id).Scan(
 		&key.ID, &key.Name, &key.KeyPrefix, &key.KeyHash, &roleID, &groupID, &scopesJSON,
-		&expiresAt, &lastUsedAt, &key.Revoked, &key.CreatedAt, &key.UpdatedAt, &roleName, &groupName)
+		&expiresAt, &lastUsedAt, &key.Revoked, &key.CreatedAt, &key.UpdatedAt, &cidrsJSON, &roleName, &groupName,
+		&projectID, &projectName, &budgetJSON, &ownerID, &ownerEmail)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -858,6 +951,8 @@ func (s *TenantStore) GetAPIKey(ctx context.Context, id string) (*domain.APIKeyW
 	}
 
 	json.Unmarshal(scopesJSON, &key.Scopes)
+	json.Unmarshal(cidrsJSON, &key.AllowedCIDRs)
+	key.Budget = unmarshalAPIKeyBudget(budgetJSON)
 	if roleID.Valid {
 		key.RoleID = roleID.String
 	}
@@ -870,6 +965,8 @@ func (s *TenantStore) GetAPIKey(ctx context.Context, id string) (*domain.APIKeyW
 	if groupName.Valid {
 		key.GroupName = groupName.String
 	}
+	key.ProjectID, key.ProjectName = projectID.String, projectName.String
+	key.OwnerUserID, key.OwnerEmail = ownerID.String, ownerEmail.String
 	if expiresAt.Valid {
 		t := expiresAt.Time
 		key.ExpiresAt = &t
@@ -885,22 +982,26 @@ func (s *TenantStore) GetAPIKey(ctx context.Context, id string) (*domain.APIKeyW
 // GetAPIKeyByHash gets an API key by its hash
 func (s *TenantStore) GetAPIKeyByHash(ctx context.Context, keyHash string) (*domain.APIKeyWithRole, error) {
 	query := `
-		SELECT k.id, k.name, k.key_prefix, k.key_hash, k.role_id, k.group_id, k.scopes, k.expires_at, k.last_used_at, k.is_revoked, k.created_at, k.updated_at,
-		       r.name as role_name, g.name as group_name
+		SELECT k.id, k.name, k.key_prefix, k.key_hash, COALESCE(k.role_id, u.api_role_id), k.group_id, k.scopes, k.expires_at, k.last_used_at, k.is_revoked, k.created_at, k.updated_at,
+		       COALESCE(k.allowed_cidrs, '[]'), r.name as role_name, g.name as group_name, k.project_id, p.name as project_name, k.budget,
+		       k.owner_user_id, u.email
 		FROM api_keys k
-		LEFT JOIN roles r ON k.role_id = r.id
+		LEFT JOIN users u ON k.owner_user_id = u.id
+		LEFT JOIN roles r ON COALESCE(k.role_id, u.api_role_id) = r.id
 		LEFT JOIN groups g ON k.group_id = g.id
-		WHERE k.key_hash = $1 AND k.is_revoked = false
+		LEFT JOIN projects p ON k.project_id = p.id
+		WHERE k.key_hash = $1 AND k.is_revoked = false AND (k.owner_user_id IS NULL OR u.is_active)
 	`
 
 	var key domain.APIKeyWithRole
-	var scopesJSON []byte
-	var roleID, roleName, groupID, groupName sql.NullString
+	var scopesJSON, cidrsJSON, budgetJSON []byte
+	var roleID, roleName, groupID, groupName, projectID, projectName, ownerID, ownerEmail sql.NullString
 	var expiresAt, lastUsedAt sql.NullTime
 
 	err := s.db.QueryRowContext(ctx, query, keyHash).Scan(
 		&key.ID, &key.Name, &key.KeyPrefix, &key.KeyHash, &roleID, &groupID, &scopesJSON,
-		&expiresAt, &lastUsedAt, &key.Revoked, &key.CreatedAt, &key.UpdatedAt, &roleName, &groupName)
+		&expiresAt, &lastUsedAt, &key.Revoked, &key.CreatedAt, &key.UpdatedAt, &cidrsJSON, &roleName, &groupName,
+		&projectID, &projectName, &budgetJSON, &ownerID, &ownerEmail)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -910,6 +1011,8 @@ func (s *TenantStore) GetAPIKeyByHash(ctx context.Context, keyHash string) (*dom
 	}
 
 	json.Unmarshal(scopesJSON, &key.Scopes)
+	json.Unmarshal(cidrsJSON, &key.AllowedCIDRs)
+	key.Budget = unmarshalAPIKeyBudget(budgetJSON)
 	if roleID.Valid {
 		key.RoleID = roleID.String
 	}
@@ -922,6 +1025,8 @@ func (s *TenantStore) GetAPIKeyByHash(ctx context.Context, keyHash string) (*dom
 	if groupName.Valid {
 		key.GroupName = groupName.String
 	}
+	key.ProjectID, key.ProjectName = projectID.String, projectName.String
+	key.OwnerUserID, key.OwnerEmail = ownerID.String, ownerEmail.String
 	if expiresAt.Valid {
 		t := expiresAt.Time
 		key.ExpiresAt = &t
@@ -943,17 +1048,31 @@ func (s *TenantStore) UpdateAPIKeyCreator(ctx context.Context, keyID string, cre
 
 // ListAPIKeys lists all API keys
 func (s *TenantStore) ListAPIKeys(ctx context.Context) ([]*domain.APIKeyWithRole, error) {
+	return s.listAPIKeys(ctx, "")
+}
+
+// ListUserAPIKeys lists the personal API keys of a user
+func (s *TenantStore) ListUserAPIKeys(ctx context.Context, userID string) ([]*domain.APIKeyWithRole, error) {
+	return s.listAPIKeys(ctx, userID)
+}
+
+// listAPIKeys lists API keys, only the ones owned by ownerUserID when set
+func (s *TenantStore) listAPIKeys(ctx context.Context, ownerUserID string) ([]*domain.APIKeyWithRole, error) {
 	query := `
-		SELECT k.id, k.name, k.key_prefix, k.role_id, k.group_id, k.scopes, k.expires_at, k.last_used_at, k.is_revoked, k.created_at, k.updated_at,
-		       k.created_by, k.created_by_email,
-		       r.name as role_name, g.name as group_name
+		SELECT k.id, k.name, k.key_prefix, COALESCE(k.role_id, u.api_role_id), k.group_id, k.scopes, k.expires_at, k.last_used_at, k.is_revoked, k.created_at, k.updated_at,
+		       k.created_by, k.created_by_email, COALESCE(k.allowed_cidrs, '[]'),
+		       r.name as role_name, g.name as group_name, k.project_id, p.name as project_name, k.budget,
+		       k.owner_user_id, u.email
 		FROM api_keys k
-		LEFT JOIN roles r ON k.role_id = r.id
+		LEFT JOIN users u ON k.owner_user_id = u.id
+		LEFT JOIN roles r ON COALESCE(k.role_id, u.api_role_id) = r.id
 		LEFT JOIN groups g ON k.group_id = g.id
+		LEFT JOIN projects p ON k.project_id = p.id
+		WHERE $1::uuid IS NULL OR k.owner_user_id = $1::uuid
 		ORDER BY k.created_at DESC
 	`
 
-	rows, err := s.db.QueryContext(ctx, query)
+	rows, err := s.db.QueryContext(ctx, query, nullString(ownerUserID))
 	if err != nil {
 		return nil, err
 	}
@@ -962,18 +1081,21 @@ func (s *TenantStore) ListAPIKeys(ctx context.Context) ([]*domain.APIKeyWithRole
 	var keys []*domain.APIKeyWithRole
 	for rows.Next() {
 		var key domain.APIKeyWithRole
-		var scopesJSON []byte
-		var roleID, roleName, groupID, groupName, createdBy, createdByEmail sql.NullString
+		var scopesJSON, cidrsJSON, budgetJSON []byte
+		var roleID, roleName, groupID, groupName, createdBy, createdByEmail, projectID, projectName, ownerID, ownerEmail sql.NullString
 		var expiresAt, lastUsedAt sql.NullTime
 
 		err := rows.Scan(&key.ID, &key.Name, &key.KeyPrefix, &roleID, &groupID, &scopesJSON,
 			&expiresAt, &lastUsedAt, &key.Revoked, &key.CreatedAt, &key.UpdatedAt,
-			&createdBy, &createdByEmail, &roleName, &groupName)
+			&createdBy, &createdByEmail, &cidrsJSON, &roleName, &groupName, &projectID, &projectName, &budgetJSON,
+			&ownerID, &ownerEmail)
 		if err != nil {
 			return nil, err
 		}
 
 		json.Unmarshal(scopesJSON, &key.Scopes)
+		json.Unmarshal(cidrsJSON, &key.AllowedCIDRs)
+		key.Budget = unmarshalAPIKeyBudget(budgetJSON)
 		if createdBy.Valid {
 			key.APIKey.CreatedBy = createdBy.String
 		}
@@ -992,6 +1114,8 @@ func (s *TenantStore) ListAPIKeys(ctx context.Context) ([]*domain.APIKeyWithRole
 		if groupName.Valid {
 			key.GroupName = groupName.String
 		}
+		key.ProjectID, key.ProjectName = projectID.String, projectName.String
+		key.OwnerUserID, key.OwnerEmail = ownerID.String, ownerEmail.String
 		if expiresAt.Valid {
 			t := expiresAt.Time
 			key.ExpiresAt = &t
@@ -1007,7 +1131,8 @@ func (s *TenantStore) ListAPIKeys(ctx context.Context) ([]*domain.APIKeyWithRole
 	return keys, nil
 }
 
-// UpdateAPIKey updates an API key's name, role, or group assignment
+// UpdateAPIKey updates an API key's name, role, or group assignment.
+// Personal keys keep using their owner's API role; only the name changes.
 func (s *TenantStore) UpdateAPIKey(ctx context.Context, id string, name string, roleID string, groupID string) error {
 	// API key can have either role_id OR group_id, not both
 	var roleIDPtr, groupIDPtr interface{}
@@ -1025,7 +1150,10 @@ func (s *TenantStore) UpdateAPIKey(ctx context.Context, id string, name string,
 
 	query := `
 		UPDATE api_keys 
-		SET name = $2, role_id = $3, group_id = $4, updated_at = $5
+		SET name = $2,
+		    role_id = CASE WHEN owner_user_id IS NULL THEN $3::uuid END,
+		    group_id = CASE WHEN owner_user_id IS NULL THEN $4::uuid END,
+		    updated_at = $5
 		WHERE id = $1
 	`
 
@@ -1033,6 +1161,16 @@ func (s *TenantStore) UpdateAPIKey(ctx context.Context, id string, name string,
 	return err
 }
 
+// UpdateAPIKeyAllowedCIDRs replaces an API key's client IP allowlist
+func (s *TenantStore) UpdateAPIKeyAllowedCIDRs(ctx context.Context, id string, cidrs []string) error {
+	if cidrs == nil {
+		cidrs = []string{}
+	}
+	cidrsJSON, _ := json.Marshal(cidrs)
+	_, err := s.db.ExecContext(ctx, "UPDATE api_keys SET allowed_cidrs = $2, updated_at = $3 WHERE id = $1", id, cidrsJSON, time.Now())
+	return err
+}
+
 // RevokeAPIKey revokes an API key
 func (s *TenantStore) RevokeAPIKey(ctx context.Context, id, reason string) error {
 	query := `UPDATE api_keys SET is_revoked = true, revoked_at = $2, revoked_reason = $3, updated_at = $4 WHERE id = $1`
@@ -1090,9 +1228,32 @@ func (s *TenantStore) SaveProviderConfig(ctx context.Context, config *domain.Pro
 	if config.RegionPrefix != "" {
 		extra["region_prefix"] = config.RegionPrefix
 	}
+	if config.KeyStrategy != "" {
+		extra["key_strategy"] = string(config.KeyStrategy)
+	} else {
+		delete(extra, "key_strategy")
+	}
 	// Store connection settings in extra_settings as JSON
 	connJSON, _ := json.Marshal(config.ConnectionSettings)
 	extra["connection_settings"] = string(connJSON)
+	if len(config.Throttles) > 0 {
+		throttlesJSON, _ := json.Marshal(config.Throttles)
+		extra["throttles"] = string(throttlesJSON)
+	} else {
+		delete(extra, "throttles")
+	}
+	if len(config.Regions) > 0 {
+		regionsJSON, _ := json.Marshal(config.Regions)
+		extra["regions"] = string(regionsJSON)
+	} else {
+		delete(extra, "regions")
+	}
+	if len(config.HuggingFaceEndpoints) > 0 {
+		endpointsJSON, _ := json.Marshal(config.HuggingFaceEndpoints)
+		extra["huggingface_endpoints"] = string(endpointsJSON)
+	} else {
+		delete(extra, "huggingface_endpoints")
+	}
 
 	extraJSON, _ := json.Marshal(extra)
 	now := time.Now()
@@ -1151,6 +1312,15 @@ func (s *TenantStore) GetProviderConfig(ctx context.Context, provider domain.Pro
 		if connStr, ok := config.ExtraSettings["connection_settings"]; ok {
 			json.Unmarshal([]byte(connStr), &config.ConnectionSettings)
 		}
+		if throttlesStr, ok := config.ExtraSettings["throttles"]; ok {
+			json.Unmarshal([]byte(throttlesStr), &config.Throttles)
+		}
+		if regionsStr, ok := config.ExtraSettings["regions"]; ok {
+			json.Unmarshal([]byte(regionsStr), &config.Regions)
+		}
+		if endpointsStr, ok := config.ExtraSettings["huggingface_endpoints"]; ok {
+			json.Unmarshal([]byte(endpointsStr), &config.HuggingFaceEndpoints)
+		}
 		// Extract Azure/Bedrock specific fields from extra_settings
 		if v, ok := config.ExtraSettings["resource_name"]; ok {
 			config.ResourceName = v
@@ -1161,6 +1331,12 @@ func (s *TenantStore) GetProviderConfig(ctx context.Context, provider domain.Pro
 		if v, ok := config.ExtraSettings["region_prefix"]; ok {
 			config.RegionPrefix = v
 		}
+		if v, ok := config.ExtraSettings["key_strategy"]; ok {
+			config.KeyStrategy = domain.KeyStrategy(v)
+		}
+	}
+	if err := s.loadCustomModels(ctx, &config); err != nil {
+		return nil, err
 	}
 
 	return &config, nil
@@ -1208,6 +1384,15 @@ func (s *TenantStore) ListProviderConfigs(ctx context.Context) ([]*domain.Provid
 			if connStr, ok := config.ExtraSettings["connection_settings"]; ok {
 				json.Unmarshal([]byte(connStr), &config.ConnectionSettings)
 			}
+			if throttlesStr, ok := config.ExtraSettings["throttles"]; ok {
+				json.Unmarshal([]byte(throttlesStr), &config.Throttles)
+			}
+			if regionsStr, ok := config.ExtraSettings["regions"]; ok {
+				json.Unmarshal([]byte(regionsStr), &config.Regions)
+			}
+			if endpointsStr, ok := config.ExtraSettings["huggingface_endpoints"]; ok {
+				json.Unmarshal([]byte(endpointsStr), &config.HuggingFaceEndpoints)
+			}
 			// Extract Azure/Bedrock specific fields from extra_settings
 			if v, ok := config.ExtraSettings["resource_name"]; ok {
 				config.ResourceName = v
@@ -1218,10 +1403,21 @@ func (s *TenantStore) ListProviderConfigs(ctx context.Context) ([]*domain.Provid
 			if v, ok := config.ExtraSettings["region_prefix"]; ok {
 				config.RegionPrefix = v
 			}
+			if v, ok := config.ExtraSettings["key_strategy"]; ok {
+				config.KeyStrategy = domain.KeyStrategy(v)
+			}
 		}
 
 		configs = append(configs, &config)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for _, config := range configs {
+		if err := s.loadCustomModels(ctx, config); err != nil {
+			return nil, err
+		}
+	}
 
 	return configs, nil
 }
@@ -1342,9 +1538,12 @@ func (s *TenantStore) RecordUsage(ctx context.Context, record *domain.UsageRecor
 
 	// Marshal metadata or use empty JSON if nil
 	var metadataJSON []byte
-	var err error
 	if record.Metadata != nil && len(record.Metadata) > 0 {
-		metadataJSON, err = json.Marshal(record.Metadata)
+		metadata, err := s.sealCapturedContent(ctx, record.Metadata)
+		if err != nil {
+			return err
+		}
+		metadataJSON, err = json.Marshal(metadata)
 		if err != nil {
 			// Fall back to empty JSON on marshal error
 			metadataJSON = []byte("{}")
@@ -1353,39 +1552,55 @@ func (s *TenantStore) RecordUsage(ctx context.Context, record *domain.UsageRecor
 		metadataJSON = []byte("{}")
 	}
 
+	// Usage of a personal key is attributed to the key's owner
 	query := `
 		INSERT INTO usage_records (id, api_key_id, request_id, model, provider, input_tokens, output_tokens,
 			total_tokens, cost_usd, latency_ms, is_success, error_code, error_message, tool_calls,
-			thinking_tokens, metadata, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+			thinking_tokens, metadata, created_at, project_id, cached_input_tokens, provider_region, cancelled_by_client,
+			time_to_first_token_ms, tokens_per_second, experiment_id, experiment_variant, user_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23,
+			$24, $25, (SELECT owner_user_id FROM api_keys WHERE id = $2))
 	`
 
-	// Convert APIKeyID to UUID or nil
-	var apiKeyID interface{}
+	// Convert APIKeyID and ProjectID to UUID or nil
+	var apiKeyID, projectID interface{}
 	if record.APIKeyID != "" {
 		apiKeyID = record.APIKeyID
 	}
+	if record.ProjectID != "" {
+		projectID = record.ProjectID
+	}
+	// Stream timing stays NULL for requests that weren't streamed
+	var ttftMs sql.NullInt64
+	var tokensPerSecond sql.NullFloat64
+	if record.TimeToFirstTokenMs > 0 {
+		ttftMs = sql.NullInt64{Int64: record.TimeToFirstTokenMs, Valid: true}
+		tokensPerSecond = sql.NullFloat64{Float64: record.TokensPerSecond, Valid: record.TokensPerSecond > 0}
+	}
 
-	_, err = s.db.ExecContext(ctx, query, record.ID, apiKeyID, record.RequestID, record.Model,
+	_, err := s.db.ExecContext(ctx, query, record.ID, apiKeyID, record.RequestID, record.Model,
 		record.Provider, record.InputTokens, record.OutputTokens, record.TotalTokens,
 		record.CostUSD, record.LatencyMs, record.Success, record.ErrorCode, record.ErrorMessage,
-		record.ToolCalls, record.ThinkingTokens, metadataJSON, record.Timestamp)
+		record.ToolCalls, record.ThinkingTokens, metadataJSON, record.Timestamp, projectID, record.CachedInputTokens,
+		nullString(record.ProviderRegion), record.CancelledByClient, ttftMs, tokensPerSecond,
+		nullString(record.ExperimentID), nullString(record.ExperimentVariant))
 	return err
 }
 
-// GetUsageStats gets usage statistics
-func (s *TenantStore) GetUsageStats(ctx context.Context, startTime, endTime time.Time) (*domain.UsageStats, error) {
+// GetUsageStats gets usage statistics, optionally for one project
+func (s *TenantStore) GetUsageStats(ctx context.Context, startTime, endTime time.Time, projectID string) (*domain.UsageStats, error) {
 	query := `
 		SELECT 
 			COUNT(*) as total_requests,
 			COALESCE(SUM(total_tokens), 0) as total_tokens,
 			COALESCE(SUM(cost_usd), 0) as total_cost
 		FROM usage_records 
-		WHERE created_at >= $1 AND created_at <= $2
+		WHERE created_at >= $1 AND created_at <= $2 AND deleted_at IS NULL
+			AND ($3::uuid IS NULL OR project_id = $3::uuid)
 	`
 
 	var stats domain.UsageStats
-	err := s.db.QueryRowContext(ctx, query, startTime, endTime).Scan(
+	err := s.analytics().QueryRowContext(ctx, query, startTime, endTime, nullString(projectID)).Scan(
 		&stats.TotalRequests, &stats.TotalTokens, &stats.TotalCostUSD)
 	if err != nil {
 		return nil, err
@@ -1394,16 +1609,17 @@ func (s *TenantStore) GetUsageStats(ctx context.Context, startTime, endTime time
 	return &stats, nil
 }
 
-// ListUsageRecords lists usage records with optional filters
-func (s *TenantStore) ListUsageRecords(ctx context.Context, startTime, endTime time.Time, model, status, apiKeyID string, limit int) ([]*domain.UsageRecord, error) {
+// ListUsageRecords lists usage records with optional filters. A userID limits
+// them to requests made with that user's personal API keys.
+func (s *TenantStore) ListUsageRecords(ctx context.Context, startTime, endTime time.Time, model, status, apiKeyID, projectID, userID string, limit int) ([]*domain.UsageRecord, error) {
 	query := `
 		SELECT ur.id, ur.api_key_id, ak.name as api_key_name, ur.request_id, ur.model, ur.provider,
 			ur.input_tokens, ur.output_tokens, ur.total_tokens, ur.cost_usd, ur.latency_ms,
 			ur.is_success, ur.error_code, ur.error_message, ur.tool_calls, ur.thinking_tokens,
-			ur.created_at
+			ur.cancelled_by_client, COALESCE(ur.time_to_first_token_ms, 0), COALESCE(ur.tokens_per_second, 0), ur.created_at
 		FROM usage_records ur
 		LEFT JOIN api_keys ak ON ur.api_key_id = ak.id
-		WHERE ur.created_at >= $1 AND ur.created_at <= $2
+		WHERE ur.created_at >= $1 AND ur.created_at <= $2 AND ur.deleted_at IS NULL
 	`
 	args := []interface{}{startTime, endTime}
 	argIndex := 3
@@ -1430,6 +1646,18 @@ func (s *TenantStore) ListUsageRecords(ctx context.Context, startTime, endTime t
 		argIndex++
 	}
 
+	if projectID != "" {
+		query += fmt.Sprintf(" AND ur.project_id = $%d", argIndex)
+		args = append(args, projectID)
+		argIndex++
+	}
+
+	if userID != "" {
+		query += fmt.Sprintf(" AND ur.user_id = $%d", argIndex)
+		args = append(args, userID)
+		argIndex++
+	}
+
 	query += " ORDER BY ur.created_at DESC"
 
 	if limit > 0 {
@@ -1452,7 +1680,7 @@ func (s *TenantStore) ListUsageRecords(ctx context.Context, startTime, endTime t
 		err := rows.Scan(&record.ID, &apiKeyID, &apiKeyName, &record.RequestID, &record.Model, &record.Provider,
 			&record.InputTokens, &record.OutputTokens, &record.TotalTokens, &record.CostUSD,
 			&record.LatencyMs, &record.Success, &errorCode, &errorMessage, &record.ToolCalls,
-			&record.ThinkingTokens, &record.Timestamp)
+			&record.ThinkingTokens, &record.CancelledByClient, &record.TimeToFirstTokenMs, &record.TokensPerSecond, &record.Timestamp)
 		if err != nil {
 			return nil, err
 		}
@@ -1481,10 +1709,12 @@ func (s *TenantStore) GetUsageRecord(ctx context.Context, id string) (*domain.Us
 		SELECT ur.id, ur.api_key_id, ak.name as api_key_name, ur.request_id, ur.model, ur.provider,
 			ur.input_tokens, ur.output_tokens, ur.total_tokens, ur.cost_usd, ur.latency_ms,
 			ur.is_success, ur.error_code, ur.error_message, ur.tool_calls, ur.thinking_tokens,
-			ur.metadata, ur.created_at
+			ur.cached_input_tokens, COALESCE(ur.provider_region, ''), ur.cancelled_by_client,
+			COALESCE(ur.time_to_first_token_ms, 0), COALESCE(ur.tokens_per_second, 0), ur.metadata, ur.created_at,
+			COALESCE(ur.user_id::text, '')
 		FROM usage_records ur
 		LEFT JOIN api_keys ak ON ur.api_key_id = ak.id
-		WHERE ur.id = $1
+		WHERE ur.id = $1 AND ur.deleted_at IS NULL
 	`
 
 	var record domain.UsageRecord
@@ -1496,7 +1726,8 @@ func (s *TenantStore) GetUsageRecord(ctx context.Context, id string) (*domain.Us
 		&record.ID, &apiKeyID, &apiKeyName, &record.RequestID, &record.Model, &record.Provider,
 		&record.InputTokens, &record.OutputTokens, &record.TotalTokens, &record.CostUSD,
 		&record.LatencyMs, &record.Success, &errorCode, &errorMessage, &record.ToolCalls,
-		&record.ThinkingTokens, &metadataJSON, &record.Timestamp)
+		&record.ThinkingTokens, &record.CachedInputTokens, &record.ProviderRegion, &record.CancelledByClient,
+		&record.TimeToFirstTokenMs, &record.TokensPerSecond, &metadataJSON, &record.Timestamp, &record.UserID)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -1530,7 +1761,7 @@ func (s *TenantStore) GetUsageRecord(ctx context.Context, id string) (*domain.Us
 }
 
 // GetUsageStatsByModel gets usage statistics grouped by model
-func (s *TenantStore) GetUsageStatsByModel(ctx context.Context, startTime, endTime time.Time) (map[string]*domain.ModelUsageStats, error) {
+func (s *TenantStore) GetUsageStatsByModel(ctx context.Context, startTime, endTime time.Time, projectID string) (map[string]*domain.ModelUsageStats, error) {
 	query := `
 		SELECT
 			model,
@@ -1539,12 +1770,13 @@ func (s *TenantStore) GetUsageStatsByModel(ctx context.Context, startTime, endTi
 			COALESCE(SUM(output_tokens), 0) as output_tokens,
 			COALESCE(SUM(cost_usd), 0) as cost_usd
 		FROM usage_records
-		WHERE created_at >= $1 AND created_at <= $2
+		WHERE created_at >= $1 AND created_at <= $2 AND deleted_at IS NULL
+			AND ($3::uuid IS NULL OR project_id = $3::uuid)
 		GROUP BY model
 		ORDER BY cost_usd DESC
 	`
 
-	rows, err := s.db.QueryContext(ctx, query, startTime, endTime)
+	rows, err := s.analytics().QueryContext(ctx, query, startTime, endTime, nullString(projectID))
 	if err != nil {
 		return nil, err
 	}
@@ -1567,8 +1799,64 @@ func (s *TenantStore) GetUsageStatsByModel(ctx context.Context, startTime, endTi
 	return stats, rows.Err()
 }
 
+// GetModelPerformance gets latency, success rate and streaming performance
+// grouped by model and provider. Stream timing only covers streamed requests.
+func (s *TenantStore) GetModelPerformance(ctx context.Context, startTime, endTime time.Time, projectID string) ([]*domain.ModelPerformance, error) {
+	query := `
+		SELECT
+			model,
+			provider,
+			COUNT(*) as requests,
+			COUNT(*) FILTER (WHERE is_success) as successful,
+			COALESCE(AVG(latency_ms), 0),
+			COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY latency_ms), 0),
+			COALESCE(percentile_cont(0.95) WITHIN GROUP (ORDER BY latency_ms), 0),
+			COALESCE(percentile_cont(0.99) WITHIN GROUP (ORDER BY latency_ms), 0),
+			COALESCE(AVG(input_tokens), 0),
+			COALESCE(AVG(output_tokens), 0),
+			COALESCE(SUM(cost_usd), 0),
+			COUNT(time_to_first_token_ms) as streamed,
+			COALESCE(AVG(time_to_first_token_ms), 0),
+			COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY time_to_first_token_ms), 0),
+			COALESCE(percentile_cont(0.95) WITHIN GROUP (ORDER BY time_to_first_token_ms), 0),
+			COALESCE(AVG(tokens_per_second), 0)
+		FROM usage_records
+		WHERE created_at >= $1 AND created_at <= $2 AND deleted_at IS NULL
+			AND ($3::uuid IS NULL OR project_id = $3::uuid)
+		GROUP BY model, provider
+		ORDER BY requests DESC
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, startTime, endTime, nullString(projectID))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var perf []*domain.ModelPerformance
+	for rows.Next() {
+		var p domain.ModelPerformance
+		err := rows.Scan(&p.Model, &p.Provider, &p.TotalRequests, &p.SuccessfulReqs,
+			&p.AvgLatencyMs, &p.P50LatencyMs, &p.P95LatencyMs, &p.P99LatencyMs,
+			&p.AvgInputTokens, &p.AvgOutputTokens, &p.TotalCostUSD, &p.StreamedRequests,
+			&p.AvgTimeToFirstTokenMs, &p.P50TimeToFirstTokenMs, &p.P95TimeToFirstTokenMs, &p.TokensPerSecond)
+		if err != nil {
+			return nil, err
+		}
+		p.FailedReqs = p.TotalRequests - p.SuccessfulReqs
+		if p.TotalRequests > 0 {
+			p.SuccessRate = float64(p.SuccessfulReqs) / float64(p.TotalRequests)
+			p.CostPerRequest = p.TotalCostUSD / float64(p.TotalRequests)
+		}
+		p.UpdatedAt = time.Now()
+		perf = append(perf, &p)
+	}
+
+	return perf, rows.Err()
+}
+
 // GetUsageStatsByProvider gets usage statistics grouped by provider
-func (s *TenantStore) GetUsageStatsByProvider(ctx context.Context, startTime, endTime time.Time) (map[string]*domain.ProviderUsageStats, error) {
+func (s *TenantStore) GetUsageStatsByProvider(ctx context.Context, startTime, endTime time.Time, projectID string) (map[string]*domain.ProviderUsageStats, error) {
 	query := `
 		SELECT
 			provider,
@@ -1577,12 +1865,13 @@ func (s *TenantStore) GetUsageStatsByProvider(ctx context.Context, startTime, en
 			COALESCE(SUM(cost_usd), 0) as cost_usd,
 			COALESCE(AVG(latency_ms), 0) as avg_latency_ms
 		FROM usage_records
-		WHERE created_at >= $1 AND created_at <= $2
+		WHERE created_at >= $1 AND created_at <= $2 AND deleted_at IS NULL
+			AND ($3::uuid IS NULL OR project_id = $3::uuid)
 		GROUP BY provider
 		ORDER BY cost_usd DESC
 	`
 
-	rows, err := s.db.QueryContext(ctx, query, startTime, endTime)
+	rows, err := s.analytics().QueryContext(ctx, query, startTime, endTime, nullString(projectID))
 	if err != nil {
 		return nil, err
 	}
@@ -1606,7 +1895,7 @@ func (s *TenantStore) GetUsageStatsByProvider(ctx context.Context, startTime, en
 }
 
 // GetUsageStatsByAPIKey gets usage statistics grouped by API key
-func (s *TenantStore) GetUsageStatsByAPIKey(ctx context.Context, startTime, endTime time.Time) (map[string]*domain.APIKeyUsageStats, error) {
+func (s *TenantStore) GetUsageStatsByAPIKey(ctx context.Context, startTime, endTime time.Time, projectID string) (map[string]*domain.APIKeyUsageStats, error) {
 	query := `
 		SELECT
 			ur.api_key_id,
@@ -1616,12 +1905,13 @@ func (s *TenantStore) GetUsageStatsByAPIKey(ctx context.Context, startTime, endT
 			COALESCE(SUM(ur.cost_usd), 0) as cost_usd
 		FROM usage_records ur
 		LEFT JOIN api_keys ak ON ur.api_key_id = ak.id
-		WHERE ur.created_at >= $1 AND ur.created_at <= $2 AND ur.api_key_id IS NOT NULL
+		WHERE ur.created_at >= $1 AND ur.created_at <= $2 AND ur.deleted_at IS NULL AND ur.api_key_id IS NOT NULL
+			AND ($3::uuid IS NULL OR ur.project_id = $3::uuid)
 		GROUP BY ur.api_key_id, ak.name
 		ORDER BY cost_usd DESC
 	`
 
-	rows, err := s.db.QueryContext(ctx, query, startTime, endTime)
+	rows, err := s.analytics().QueryContext(ctx, query, startTime, endTime, nullString(projectID))
 	if err != nil {
 		return nil, err
 	}
@@ -1646,7 +1936,7 @@ func (s *TenantStore) GetUsageStatsByAPIKey(ctx context.Context, startTime, endT
 }
 
 // GetUsageTimeSeries gets usage over time for charts
-func (s *TenantStore) GetUsageTimeSeries(ctx context.Context, startTime, endTime time.Time, interval string) ([]*domain.UsageTimePoint, error) {
+func (s *TenantStore) GetUsageTimeSeries(ctx context.Context, startTime, endTime time.Time, interval, projectID string) ([]*domain.UsageTimePoint, error) {
 	// interval can be "hour", "day", "week", "month"
 	var truncFunc string
 	switch interval {
@@ -1669,12 +1959,13 @@ func (s *TenantStore) GetUsageTimeSeries(ctx context.Context, startTime, endTime
 			COALESCE(SUM(total_tokens), 0) as total_tokens,
 			COALESCE(SUM(cost_usd), 0) as cost_usd
 		FROM usage_records
-		WHERE created_at >= $1 AND created_at <= $2
+		WHERE created_at >= $1 AND created_at <= $2 AND deleted_at IS NULL
+			AND ($3::uuid IS NULL OR project_id = $3::uuid)
 		GROUP BY time_bucket
 		ORDER BY time_bucket ASC
 	`, truncFunc)
 
-	rows, err := s.db.QueryContext(ctx, query, startTime, endTime)
+	rows, err := s.analytics().QueryContext(ctx, query, startTime, endTime, nullString(projectID))
 	if err != nil {
 		return nil, err
 	}
@@ -1922,7 +2213,7 @@ type AvailableModel struct {
 
 // SaveAvailableModels saves models fetched from a provider API
 func (s *TenantStore) SaveAvailableModels(ctx context.Context, provider string, models []domain.ModelInfo) error {
-	tx, err := s.db.BeginTx(ctx, nil)
+	tx, err := s.beginTx(ctx)
 	if err != nil {
 		return fmt.Errorf("begin transaction: %w", err)
 	}
@@ -1930,66 +2221,74 @@ func (s *TenantStore) SaveAvailableModels(ctx context.Context, provider string,
 
 	// Upsert each model
 	for _, model := range models {
-		// Store empty metadata for now (can be extended later)
-		metadataJSON := []byte("{}")
-
-		// Use NativeModelID if provided, otherwise use ID
-		nativeModelID := model.NativeModelID
-		if nativeModelID == "" {
-			nativeModelID = model.ID
+		if err := upsertAvailableModel(ctx, tx, model); err != nil {
+			return err
 		}
+	}
 
-		_, err := tx.ExecContext(ctx, `
-			INSERT INTO available_models (
-				provider, model_id, model_name, native_model_id, description,
-				supports_tools, supports_vision, supports_reasoning, supports_streaming,
-				context_window, max_output_tokens,
-				input_cost_per_1m, output_cost_per_1m,
-				provider_metadata, is_available, is_deprecated, fetched_at
-			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, NOW())
-			ON CONFLICT (provider, model_id)
-			DO UPDATE SET
-				model_name = EXCLUDED.model_name,
-				native_model_id = EXCLUDED.native_model_id,
-				description = EXCLUDED.description,
-				supports_tools = EXCLUDED.supports_tools,
-				supports_vision = EXCLUDED.supports_vision,
-				supports_reasoning = EXCLUDED.supports_reasoning,
-				supports_streaming = EXCLUDED.supports_streaming,
-				context_window = EXCLUDED.context_window,
-				max_output_tokens = EXCLUDED.max_output_tokens,
-				input_cost_per_1m = EXCLUDED.input_cost_per_1m,
-				output_cost_per_1m = EXCLUDED.output_cost_per_1m,
-				provider_metadata = EXCLUDED.provider_metadata,
-				is_available = EXCLUDED.is_available,
-				is_deprecated = EXCLUDED.is_deprecated,
-				fetched_at = NOW(),
-				updated_at = NOW()
-		`,
-			string(model.Provider),
-			model.ID,
-			model.Name,
-			nativeModelID,
-			"", // description from API if available
-			model.SupportsTools,
-			false, // supports_vision (could be added to ModelInfo)
-			model.SupportsReasoning,
-			true, // supports_streaming (default true)
-			model.ContextLimit,
-			model.OutputLimit,
-			model.InputCostPer1M,
-			model.OutputCostPer1M,
-			metadataJSON,
-			model.Enabled,
-			false, // is_deprecated
-		)
+	return tx.Commit()
+}
 
-		if err != nil {
-			return fmt.Errorf("insert model %s: %w", model.ID, err)
-		}
+// upsertAvailableModel inserts or refreshes a provider model's catalog entry
+func upsertAvailableModel(ctx context.Context, tx Querier, model domain.ModelInfo) error {
+	// Store empty metadata for now (can be extended later)
+	metadataJSON := []byte("{}")
+
+	// Use NativeModelID if provided, otherwise use ID
+	nativeModelID := model.NativeModelID
+	if nativeModelID == "" {
+		nativeModelID = model.ID
 	}
 
-	return tx.Commit()
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO available_models (
+			provider, model_id, model_name, native_model_id, description,
+			supports_tools, supports_vision, supports_reasoning, supports_streaming,
+			context_window, max_output_tokens,
+			input_cost_per_1m, output_cost_per_1m,
+			provider_metadata, is_available, is_deprecated, fetched_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, NOW())
+		ON CONFLICT (provider, model_id)
+		DO UPDATE SET
+			model_name = EXCLUDED.model_name,
+			native_model_id = EXCLUDED.native_model_id,
+			description = EXCLUDED.description,
+			supports_tools = EXCLUDED.supports_tools,
+			supports_vision = EXCLUDED.supports_vision,
+			supports_reasoning = EXCLUDED.supports_reasoning,
+			supports_streaming = EXCLUDED.supports_streaming,
+			context_window = EXCLUDED.context_window,
+			max_output_tokens = EXCLUDED.max_output_tokens,
+			input_cost_per_1m = EXCLUDED.input_cost_per_1m,
+			output_cost_per_1m = EXCLUDED.output_cost_per_1m,
+			provider_metadata = EXCLUDED.provider_metadata,
+			is_available = EXCLUDED.is_available,
+			is_deprecated = EXCLUDED.is_deprecated,
+			fetched_at = NOW(),
+			updated_at = NOW()
+	`,
+		string(model.Provider),
+		model.ID,
+		model.Name,
+		nativeModelID,
+		"", // description from API if available
+		model.SupportsTools,
+		model.SupportsVision,
+		model.SupportsReasoning,
+		true, // supports_streaming (default true)
+		model.ContextLimit,
+		model.OutputLimit,
+		model.InputCostPer1M,
+		model.OutputCostPer1M,
+		metadataJSON,
+		model.Enabled,
+		false, // is_deprecated
+	)
+
+	if err != nil {
+		return fmt.Errorf("insert model %s: %w", model.ID, err)
+	}
+	return nil
 }
 
 // ListAvailableModels returns all available models
@@ -2103,6 +2402,7 @@ func (s *TenantStore) ListAvailableModelsForAPI(ctx context.Context) ([]domain.M
 			Provider:          domain.Provider(am.Provider),
 			SupportsTools:     am.SupportsTools,
 			SupportsReasoning: am.SupportsReasoning,
+			SupportsVision:    am.SupportsVision,
 			ContextLimit:      uint32(am.ContextWindow),
 			OutputLimit:       uint32(am.MaxOutputTokens),
 			InputCostPer1M:    am.InputCostPer1M,
@@ -2153,48 +2453,15 @@ func (s *TenantStore) CreateAuditLog(ctx context.Context, log *domain.AuditLog)
 
 // ListAuditLogs retrieves audit logs with filtering
 func (s *TenantStore) ListAuditLogs(ctx context.Context, filter domain.AuditLogFilter) ([]domain.AuditLog, error) {
+	where, args := auditLogConditions(filter)
 	query := `
 		SELECT id, timestamp, action, resource_type, resource_id, resource_name,
 			   actor_id, actor_email, actor_type, ip_address, user_agent,
 			   details, old_value, new_value, status, error_message
 		FROM audit_logs
-		WHERE 1=1
-	`
-	args := []interface{}{}
-	argIdx := 1
-
-	if filter.ResourceType != "" {
-		query += fmt.Sprintf(" AND resource_type = $%d", argIdx)
-		args = append(args, filter.ResourceType)
-		argIdx++
-	}
-	if filter.ResourceID != "" {
-		query += fmt.Sprintf(" AND resource_id = $%d", argIdx)
-		args = append(args, filter.ResourceID)
-		argIdx++
-	}
-	if filter.Action != "" {
-		query += fmt.Sprintf(" AND action = $%d", argIdx)
-		args = append(args, filter.Action)
-		argIdx++
-	}
-	if filter.ActorID != "" {
-		query += fmt.Sprintf(" AND actor_id = $%d", argIdx)
-		args = append(args, filter.ActorID)
-		argIdx++
-	}
-	if !filter.StartTime.IsZero() {
-		query += fmt.Sprintf(" AND timestamp >= $%d", argIdx)
-		args = append(args, filter.StartTime)
-		argIdx++
-	}
-	if !filter.EndTime.IsZero() {
-		query += fmt.Sprintf(" AND timestamp <= $%d", argIdx)
-		args = append(args, filter.EndTime)
-		argIdx++
-	}
-
-	query += " ORDER BY timestamp DESC"
+		WHERE ` + where + `
+		ORDER BY timestamp DESC`
+	argIdx := len(args) + 1
 
 	if filter.Limit > 0 {
 		query += fmt.Sprintf(" LIMIT $%d", argIdx)
@@ -2214,62 +2481,71 @@ func (s *TenantStore) ListAuditLogs(ctx context.Context, filter domain.AuditLogF
 
 	var logs []domain.AuditLog
 	for rows.Next() {
-		var log domain.AuditLog
-		var detailsJSON, oldValueJSON, newValueJSON []byte
-		var actorEmail, ipAddress, userAgent, errorMessage sql.NullString
-
-		err := rows.Scan(
-			&log.ID, &log.Timestamp, &log.Action, &log.ResourceType, &log.ResourceID, &log.ResourceName,
-			&log.ActorID, &actorEmail, &log.ActorType, &ipAddress, &userAgent,
-			&detailsJSON, &oldValueJSON, &newValueJSON, &log.Status, &errorMessage,
-		)
+		log, err := scanAuditLog(rows)
 		if err != nil {
 			return nil, err
 		}
-
-		log.ActorEmail = actorEmail.String
-		log.IPAddress = ipAddress.String
-		log.UserAgent = userAgent.String
-		log.ErrorMessage = errorMessage.String
-
-		json.Unmarshal(detailsJSON, &log.Details)
-		json.Unmarshal(oldValueJSON, &log.OldValue)
-		json.Unmarshal(newValueJSON, &log.NewValue)
-
-		logs = append(logs, log)
+		logs = append(logs, *log)
 	}
 
 	return logs, rows.Err()
 }
 
-// GetAuditLog retrieves a single audit log by ID
-func (s *TenantStore) GetAuditLog(ctx context.Context, id string) (*domain.AuditLog, error) {
-	query := `
-		SELECT id, timestamp, action, resource_type, resource_id, resource_name,
-			   actor_id, actor_email, actor_type, ip_address, user_agent,
-			   details, old_value, new_value, status, error_message
-		FROM audit_logs WHERE id = $1
-	`
+// auditLogConditions builds the WHERE clause shared by the audit log queries
+func auditLogConditions(filter domain.AuditLogFilter) (string, []interface{}) {
+	conditions := []string{"deleted_at IS NULL"}
+	args := []interface{}{}
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.ResourceType != "" {
+		add("resource_type = $%d", filter.ResourceType)
+	}
+	if filter.ResourceID != "" {
+		add("resource_id = $%d", filter.ResourceID)
+	}
+	if filter.Action != "" {
+		add("action = $%d", filter.Action)
+	}
+	if filter.ActorID != "" {
+		add("actor_id = $%d", filter.ActorID)
+	}
+	if filter.ActorEmail != "" {
+		add("LOWER(actor_email) = LOWER($%d)", filter.ActorEmail)
+	}
+	if !filter.StartTime.IsZero() {
+		add("timestamp >= $%d", filter.StartTime)
+	}
+	if !filter.EndTime.IsZero() {
+		add("timestamp <= $%d", filter.EndTime)
+	}
+	return strings.Join(conditions, " AND "), args
+}
 
+// scanAuditLog reads one audit_logs row selected with the standard column list
+func scanAuditLog(row interface{ Scan(...any) error }) (*domain.AuditLog, error) {
 	var log domain.AuditLog
 	var detailsJSON, oldValueJSON, newValueJSON []byte
-	var actorEmail, ipAddress, userAgent, errorMessage sql.NullString
+	var resourceName, actorID, actorEmail, actorType, ipAddress, userAgent, status, errorMessage sql.NullString
 
-	err := s.db.QueryRowContext(ctx, query, id).Scan(
-		&log.ID, &log.Timestamp, &log.Action, &log.ResourceType, &log.ResourceID, &log.ResourceName,
-		&log.ActorID, &actorEmail, &log.ActorType, &ipAddress, &userAgent,
-		&detailsJSON, &oldValueJSON, &newValueJSON, &log.Status, &errorMessage,
+	err := row.Scan(
+		&log.ID, &log.Timestamp, &log.Action, &log.ResourceType, &log.ResourceID, &resourceName,
+		&actorID, &actorEmail, &actorType, &ipAddress, &userAgent,
+		&detailsJSON, &oldValueJSON, &newValueJSON, &status, &errorMessage,
 	)
-	if err == sql.ErrNoRows {
-		return nil, nil
-	}
 	if err != nil {
 		return nil, err
 	}
 
+	log.ResourceName = resourceName.String
+	log.ActorID = actorID.String
 	log.ActorEmail = actorEmail.String
+	log.ActorType = actorType.String
 	log.IPAddress = ipAddress.String
 	log.UserAgent = userAgent.String
+	log.Status = status.String
 	log.ErrorMessage = errorMessage.String
 
 	json.Unmarshal(detailsJSON, &log.Details)
@@ -2279,29 +2555,28 @@ func (s *TenantStore) GetAuditLog(ctx context.Context, id string) (*domain.Audit
 	return &log, nil
 }
 
-// CountAuditLogs returns the count of audit logs matching the filter
-func (s *TenantStore) CountAuditLogs(ctx context.Context, filter domain.AuditLogFilter) (int, error) {
-	query := `SELECT COUNT(*) FROM audit_logs WHERE 1=1`
-	args := []interface{}{}
-	argIdx := 1
+// GetAuditLog retrieves a single audit log by ID
+func (s *TenantStore) GetAuditLog(ctx context.Context, id string) (*domain.AuditLog, error) {
+	query := `
+		SELECT id, timestamp, action, resource_type, resource_id, resource_name,
+			   actor_id, actor_email, actor_type, ip_address, user_agent,
+			   details, old_value, new_value, status, error_message
+		FROM audit_logs WHERE id = $1 AND deleted_at IS NULL
+	`
 
-	if filter.ResourceType != "" {
-		query += fmt.Sprintf(" AND resource_type = $%d", argIdx)
-		args = append(args, filter.ResourceType)
-		argIdx++
-	}
-	if filter.Action != "" {
-		query += fmt.Sprintf(" AND action = $%d", argIdx)
-		args = append(args, filter.Action)
-		argIdx++
-	}
-	if filter.ActorID != "" {
-		query += fmt.Sprintf(" AND actor_id = $%d", argIdx)
-		args = append(args, filter.ActorID)
+	log, err := scanAuditLog(s.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
 	}
+	return log, err
+}
+
+// CountAuditLogs returns the count of audit logs matching the filter
+func (s *TenantStore) CountAuditLogs(ctx context.Context, filter domain.AuditLogFilter) (int, error) {
+	where, args := auditLogConditions(filter)
 
 	var count int
-	err := s.db.QueryRowContext(ctx, query, args...).Scan(&count)
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM audit_logs WHERE `+where, args...).Scan(&count)
 	return count, err
 }
 
@@ -2320,5 +2595,5 @@ func hashAPIKey(key string) string {
 
 // AgentDashboardStore returns an agent dashboard store for this tenant
 func (s *TenantStore) AgentDashboardStore() *AgentDashboardStore {
-	return NewAgentDashboardStore(s.db)
+	return NewAgentDashboardStore(s.pool)
 }
@@ -0,0 +1,193 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+// ============================================================================
+// Conversation threads
+// ============================================================================
+
+// CreateThread creates a thread with its initial messages in one transaction
+func (s *TenantStore) CreateThread(ctx context.Context, thread *domain.Thread, msgs []domain.Message) error {
+	encoded, size, err := encodeThreadMessages(msgs)
+	if err != nil {
+		return err
+	}
+	metadata, err := json.Marshal(thread.Metadata)
+	if err != nil {
+		return fmt.Errorf("encode metadata: %w", err)
+	}
+	if thread.Metadata == nil {
+		metadata = []byte("{}")
+	}
+
+	tx, err := s.beginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO threads (api_key_id, metadata, message_count, size_bytes, expires_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`, nullString(thread.APIKeyID), metadata, len(msgs), size, thread.ExpiresAt, now, now).Scan(&thread.ID)
+	if err != nil {
+		return fmt.Errorf("insert thread: %w", err)
+	}
+	if err := insertThreadMessages(ctx, tx, thread.ID, 1, msgs, encoded); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	thread.MessageCount = len(msgs)
+	thread.SizeBytes = size
+	thread.CreatedAt = now
+	thread.UpdatedAt = now
+	return nil
+}
+
+// GetThread gets a thread by ID, or nil if it does not exist
+func (s *TenantStore) GetThread(ctx context.Context, id string) (*domain.Thread, error) {
+	var thread domain.Thread
+	var apiKeyID sql.NullString
+	var metadata []byte
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, api_key_id, metadata, message_count, size_bytes, expires_at, created_at, updated_at
+		FROM threads WHERE id = $1
+	`, id).Scan(&thread.ID, &apiKeyID, &metadata, &thread.MessageCount, &thread.SizeBytes,
+		&thread.ExpiresAt, &thread.CreatedAt, &thread.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	thread.APIKeyID = apiKeyID.String
+	if len(metadata) > 0 {
+		json.Unmarshal(metadata, &thread.Metadata)
+	}
+	return &thread, nil
+}
+
+// AppendThreadMessages adds messages to the end of a thread and pushes back its expiry.
+// Returns false, without adding anything, if the thread would exceed maxMessages or
+// maxBytes (zero means no limit) or no longer exists.
+func (s *TenantStore) AppendThreadMessages(ctx context.Context, threadID string, msgs []domain.Message, maxMessages int, maxBytes int64, expiresAt time.Time) (bool, error) {
+	encoded, size, err := encodeThreadMessages(msgs)
+	if err != nil {
+		return false, err
+	}
+
+	tx, err := s.beginTx(ctx)
+	if err != nil {
+		return false, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// The guarded update locks the thread row, so concurrent appends get consecutive seqs
+	var count int
+	err = tx.QueryRowContext(ctx, `
+		UPDATE threads SET
+			message_count = message_count + $2,
+			size_bytes = size_bytes + $3,
+			expires_at = $4
+		WHERE id = $1
+			AND ($5 <= 0 OR message_count + $2 <= $5)
+			AND ($6 <= 0 OR size_bytes + $3 <= $6)
+		RETURNING message_count
+	`, threadID, len(msgs), size, expiresAt, maxMessages, maxBytes).Scan(&count)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("update thread: %w", err)
+	}
+	if err := insertThreadMessages(ctx, tx, threadID, count-len(msgs)+1, msgs, encoded); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// ListThreadMessages returns every message in a thread, oldest first
+func (s *TenantStore) ListThreadMessages(ctx context.Context, threadID string) ([]*domain.ThreadMessage, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, thread_id, seq, message, created_at
+		FROM thread_messages WHERE thread_id = $1
+		ORDER BY seq
+	`, threadID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var msgs []*domain.ThreadMessage
+	for rows.Next() {
+		var msg domain.ThreadMessage
+		var raw []byte
+		if err := rows.Scan(&msg.ID, &msg.ThreadID, &msg.Seq, &raw, &msg.CreatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(raw, &msg.Message); err != nil {
+			return nil, fmt.Errorf("decode thread message %s: %w", msg.ID, err)
+		}
+		msgs = append(msgs, &msg)
+	}
+	return msgs, rows.Err()
+}
+
+// DeleteThread deletes a thread and its messages. Returns false if it did not exist.
+func (s *TenantStore) DeleteThread(ctx context.Context, id string) (bool, error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM threads WHERE id = $1`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// DeleteExpiredThreads deletes threads whose expiry has passed and returns how many were removed
+func (s *TenantStore) DeleteExpiredThreads(ctx context.Context, now time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM threads WHERE expires_at < $1`, now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// encodeThreadMessages marshals messages and returns their total encoded size
+func encodeThreadMessages(msgs []domain.Message) ([][]byte, int64, error) {
+	encoded := make([][]byte, len(msgs))
+	var size int64
+	for i, msg := range msgs {
+		b, err := json.Marshal(msg)
+		if err != nil {
+			return nil, 0, fmt.Errorf("encode thread message: %w", err)
+		}
+		encoded[i] = b
+		size += int64(len(b))
+	}
+	return encoded, size, nil
+}
+
+func insertThreadMessages(ctx context.Context, tx Querier, threadID string, firstSeq int, msgs []domain.Message, encoded [][]byte) error {
+	for i, msg := range msgs {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO thread_messages (thread_id, seq, role, message)
+			VALUES ($1, $2, $3, $4)
+		`, threadID, firstSeq+i, msg.Role, encoded[i]); err != nil {
+			return fmt.Errorf("insert thread message: %w", err)
+		}
+	}
+	return nil
+}
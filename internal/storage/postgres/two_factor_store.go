@@ -0,0 +1,228 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"modelgate/internal/domain"
+
+	"github.com/lib/pq"
+)
+
+// ============================================================================
+// Two-Factor Authentication
+// ============================================================================
+
+// GetTwoFactorSettings returns the two-factor settings, or nil if they were
+// never set
+func (s *TenantStore) GetTwoFactorSettings(ctx context.Context) (*domain.TwoFactorSettings, error) {
+	var settings domain.TwoFactorSettings
+	err := s.db.QueryRowContext(ctx, `
+		SELECT require_for_admins, require_for_all, COALESCE(updated_by, ''), updated_at
+		FROM two_factor_settings
+	`).Scan(&settings.RequireForAdmins, &settings.RequireForAll, &settings.UpdatedBy, &settings.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// SaveTwoFactorSettings replaces the two-factor settings
+func (s *TenantStore) SaveTwoFactorSettings(ctx context.Context, settings *domain.TwoFactorSettings) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO two_factor_settings (id, require_for_admins, require_for_all, updated_by, updated_at)
+		VALUES (TRUE, $1, $2, $3, $4)
+		ON CONFLICT (id) DO UPDATE SET
+			require_for_admins = EXCLUDED.require_for_admins,
+			require_for_all = EXCLUDED.require_for_all,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = EXCLUDED.updated_at
+	`, settings.RequireForAdmins, settings.RequireForAll, nullString(settings.UpdatedBy), settings.UpdatedAt)
+	return err
+}
+
+// sealTwoFactorSecret returns a TOTP secret as stored: in an envelope when
+// encryption is configured
+func (s *TenantStore) sealTwoFactorSecret(secret string) (string, error) {
+	if s.encryption == nil {
+		return secret, nil
+	}
+	env, err := s.encryption.Seal([]byte(secret))
+	if err != nil {
+		return "", fmt.Errorf("encrypt two-factor secret: %w", err)
+	}
+	data, err := json.Marshal(env)
+	return string(data), err
+}
+
+func (s *TenantStore) openTwoFactorSecret(stored string) (string, error) {
+	env, ok := parseAuthEnvelope([]byte(stored))
+	if !ok {
+		return stored, nil
+	}
+	if s.encryption == nil {
+		return "", fmt.Errorf("two-factor secret is encrypted but no encryption key is configured")
+	}
+	plain, err := s.encryption.Open(env)
+	if err != nil {
+		return "", fmt.Errorf("decrypt two-factor secret: %w", err)
+	}
+	return string(plain), nil
+}
+
+// GetUserTwoFactor returns a user's enrollment, or nil if they have none
+func (s *TenantStore) GetUserTwoFactor(ctx context.Context, userID string) (*domain.UserTwoFactor, error) {
+	t := domain.UserTwoFactor{UserID: userID}
+	var secret string
+	err := s.db.QueryRowContext(ctx, `
+		SELECT secret, enabled, confirmed_at, last_used_step, created_at,
+		       (SELECT COUNT(*) FROM user_backup_codes b WHERE b.user_id = t.user_id AND b.used_at IS NULL)
+		FROM user_two_factor t WHERE user_id = $1
+	`, userID).Scan(&secret, &t.Enabled, &t.ConfirmedAt, &t.LastUsedStep, &t.CreatedAt, &t.BackupCodesLeft)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if t.Secret, err = s.openTwoFactorSecret(secret); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// SaveUserTwoFactorSecret starts an enrollment with a new secret, replacing
+// one that wasn't confirmed. It returns false if the user is already enrolled.
+func (s *TenantStore) SaveUserTwoFactorSecret(ctx context.Context, userID, secret string) (bool, error) {
+	sealed, err := s.sealTwoFactorSecret(secret)
+	if err != nil {
+		return false, err
+	}
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO user_two_factor (user_id, secret) VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET secret = EXCLUDED.secret, last_used_step = 0, created_at = NOW()
+		WHERE NOT user_two_factor.enabled
+	`, userID, sealed)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// EnableUserTwoFactor confirms an enrollment with the time step of the code
+// that confirmed it, and gives the user their backup codes. It returns false
+// if there is no enrollment to confirm.
+func (s *TenantStore) EnableUserTwoFactor(ctx context.Context, userID string, step int64, backupCodeHashes []string) (bool, error) {
+	tx, err := s.beginTx(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE user_two_factor SET enabled = TRUE, confirmed_at = NOW(), last_used_step = $2
+		WHERE user_id = $1 AND NOT enabled
+	`, userID, step)
+	if err != nil {
+		return false, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return false, nil
+	}
+	if err := replaceBackupCodes(ctx, tx, userID, backupCodeHashes); err != nil {
+		return false, err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE users SET two_factor_failures = 0 WHERE id = $1`, userID); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// ReplaceBackupCodes replaces a user's backup codes
+func (s *TenantStore) ReplaceBackupCodes(ctx context.Context, userID string, hashes []string) error {
+	tx, err := s.beginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := replaceBackupCodes(ctx, tx, userID, hashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func replaceBackupCodes(ctx context.Context, tx Querier, userID string, hashes []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_backup_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO user_backup_codes (user_id, code_hash) SELECT $1, unnest($2::text[])
+	`, userID, pq.Array(hashes))
+	return err
+}
+
+// UseTwoFactorStep accepts a code of a time step after the last accepted one.
+// It returns false for a code that was already used.
+func (s *TenantStore) UseTwoFactorStep(ctx context.Context, userID string, step int64) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE user_two_factor SET last_used_step = $2 WHERE user_id = $1 AND enabled AND last_used_step < $2
+	`, userID, step)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// UseBackupCode marks an unused backup code used. It returns false if the
+// user has no such unused code.
+func (s *TenantStore) UseBackupCode(ctx context.Context, userID, hash string) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE user_backup_codes SET used_at = NOW() WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+	`, userID, hash)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// DeleteUserTwoFactor removes a user's enrollment and backup codes. It
+// returns false if they had no enrollment.
+func (s *TenantStore) DeleteUserTwoFactor(ctx context.Context, userID string) (bool, error) {
+	tx, err := s.beginTx(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM user_two_factor WHERE user_id = $1`, userID)
+	if err != nil {
+		return false, err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_backup_codes WHERE user_id = $1`, userID); err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, tx.Commit()
+}
+
+// RecordFailedTwoFactor counts a wrong two-factor code towards locking the
+// account. It returns when the lock ends, or zero if the account isn't locked.
+func (s *TenantStore) RecordFailedTwoFactor(ctx context.Context, userID string) (time.Time, error) {
+	return s.recordFailure(ctx, userID, "two_factor_failures", time.Now())
+}
+
+// ResetTwoFactorFailures starts the count of wrong codes and lockouts over
+// after a code was accepted
+func (s *TenantStore) ResetTwoFactorFailures(ctx context.Context, userID string) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE users SET two_factor_failures = 0, lockout_count = 0 WHERE id = $1`, userID)
+	return err
+}
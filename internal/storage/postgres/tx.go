@@ -0,0 +1,89 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Querier is satisfied by *DB and *sql.Tx, so store methods run the same
+// statements against the pool or inside a transaction
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// InTx runs fn as one unit of work: every statement fn runs through the
+// store it is given is committed together, or rolled back if fn returns an
+// error or panics. Calling InTx on a store that is already scoped to a
+// transaction runs fn in that transaction, so units of work compose.
+func (s *TenantStore) InTx(ctx context.Context, fn func(tx *TenantStore) error) error {
+	tx, err := s.beginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(s.withTx(tx.Tx)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// InTransaction reports whether the store is scoped to a transaction
+func (s *TenantStore) InTransaction() bool {
+	return s.tx != nil
+}
+
+// withTx returns a copy of the store that runs its statements in tx
+func (s *TenantStore) withTx(tx *sql.Tx) *TenantStore {
+	if s.tx == tx {
+		return s
+	}
+	return &TenantStore{
+		db:         tx,
+		pool:       s.pool,
+		tx:         tx,
+		tenantSlug: s.tenantSlug,
+		encryption: s.encryption,
+		passwords:  s.passwords,
+		shared:     s.shared,
+	}
+}
+
+// storeTx is a transaction begun by beginTx. On a store scoped to a
+// transaction it joins that transaction instead, and leaves Commit and
+// Rollback to the unit of work that began it; an error returned through
+// that unit of work still rolls everything back.
+type storeTx struct {
+	*sql.Tx
+	joined bool
+}
+
+// beginTx begins a transaction on the pool, or joins the store's transaction
+func (s *TenantStore) beginTx(ctx context.Context) (*storeTx, error) {
+	if s.tx != nil {
+		return &storeTx{Tx: s.tx, joined: true}, nil
+	}
+	tx, err := s.pool.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &storeTx{Tx: tx}, nil
+}
+
+// Commit commits the transaction unless it was joined
+func (t *storeTx) Commit() error {
+	if t.joined {
+		return nil
+	}
+	return t.Tx.Commit()
+}
+
+// Rollback rolls back the transaction unless it was joined
+func (t *storeTx) Rollback() error {
+	if t.joined {
+		return nil
+	}
+	return t.Tx.Rollback()
+}
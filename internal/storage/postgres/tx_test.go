@@ -0,0 +1,167 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"modelgate/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// roleExists reports whether the role is visible outside any transaction
+func roleExists(t *testing.T, s *TenantStore, id string) bool {
+	t.Helper()
+	role, err := s.GetRole(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return role != nil
+}
+
+func newTxRole() *domain.Role {
+	return &domain.Role{Name: "tx-" + uuid.NewString()[:8]}
+}
+
+func TestInTxCommits(t *testing.T) {
+	s := testStore(t)
+	ctx := context.Background()
+
+	role := newTxRole()
+	t.Cleanup(func() { s.DeleteRole(ctx, role.ID) })
+
+	err := s.InTx(ctx, func(tx *TenantStore) error {
+		if !tx.InTransaction() || s.InTransaction() {
+			t.Fatal("expected only the store passed to fn to be scoped to the transaction")
+		}
+		if err := tx.CreateRole(ctx, role); err != nil {
+			return err
+		}
+		if roleExists(t, s, role.ID) {
+			t.Fatal("expected the role to be invisible outside the transaction before commit")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !roleExists(t, s, role.ID) {
+		t.Fatal("expected the role to be committed")
+	}
+}
+
+func TestInTxRollsBack(t *testing.T) {
+	s := testStore(t)
+	ctx := context.Background()
+
+	t.Run("error", func(t *testing.T) {
+		role := newTxRole()
+		t.Cleanup(func() { s.DeleteRole(ctx, role.ID) })
+
+		errFailed := errors.New("failed")
+		err := s.InTx(ctx, func(tx *TenantStore) error {
+			if err := tx.CreateRole(ctx, role); err != nil {
+				return err
+			}
+			return errFailed
+		})
+		if !errors.Is(err, errFailed) {
+			t.Fatalf("expected fn's error, got %v", err)
+		}
+		if roleExists(t, s, role.ID) {
+			t.Fatal("expected the role to be rolled back")
+		}
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		role := newTxRole()
+		t.Cleanup(func() { s.DeleteRole(ctx, role.ID) })
+
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatal("expected the panic to propagate")
+				}
+			}()
+			s.InTx(ctx, func(tx *TenantStore) error {
+				if err := tx.CreateRole(ctx, role); err != nil {
+					return err
+				}
+				panic("failed")
+			})
+		}()
+		if roleExists(t, s, role.ID) {
+			t.Fatal("expected the role to be rolled back")
+		}
+	})
+}
+
+func TestInTxJoinsOuterTransaction(t *testing.T) {
+	s := testStore(t)
+	ctx := context.Background()
+
+	outer, inner := newTxRole(), newTxRole()
+	t.Cleanup(func() {
+		s.DeleteRole(ctx, outer.ID)
+		s.DeleteRole(ctx, inner.ID)
+	})
+
+	errFailed := errors.New("failed")
+	err := s.InTx(ctx, func(tx *TenantStore) error {
+		if err := tx.CreateRole(ctx, outer); err != nil {
+			return err
+		}
+		err := tx.InTx(ctx, func(nested *TenantStore) error {
+			if nested != tx {
+				t.Fatal("expected the nested unit of work to run in the outer transaction")
+			}
+			return nested.CreateRole(ctx, inner)
+		})
+		if err != nil {
+			return err
+		}
+		// The nested unit of work left the commit to the outer one
+		if roleExists(t, s, inner.ID) {
+			t.Fatal("expected the nested work to be uncommitted until the outer transaction commits")
+		}
+		return errFailed
+	})
+	if !errors.Is(err, errFailed) {
+		t.Fatalf("expected fn's error, got %v", err)
+	}
+	if roleExists(t, s, outer.ID) || roleExists(t, s, inner.ID) {
+		t.Fatal("expected the outer rollback to undo the nested work")
+	}
+}
+
+func TestDBInTransaction(t *testing.T) {
+	s := testStore(t)
+	ctx := context.Background()
+
+	if _, ok := s.DB().(*DB); !ok {
+		t.Fatalf("expected DB to return the pool outside a transaction, got %T", s.DB())
+	}
+
+	role := newTxRole()
+	t.Cleanup(func() { s.DeleteRole(ctx, role.ID) })
+
+	s.InTx(ctx, func(tx *TenantStore) error {
+		if _, ok := tx.DB().(*sql.Tx); !ok {
+			t.Fatalf("expected DB to return the transaction, got %T", tx.DB())
+		}
+		if tx.Pool() != s.Pool() {
+			t.Fatal("expected Pool to return the store's pool")
+		}
+		if err := tx.CreateRole(ctx, role); err != nil {
+			t.Fatal(err)
+		}
+		// Statements run through DB see the transaction's uncommitted writes
+		var name string
+		if err := tx.DB().QueryRowContext(ctx, "SELECT name FROM roles WHERE id = $1", role.ID).Scan(&name); err != nil || name != role.Name {
+			t.Fatalf("expected DB to read the uncommitted role, got %q, %v", name, err)
+		}
+		return errors.New("roll back")
+	})
+}
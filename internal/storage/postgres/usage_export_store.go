@@ -0,0 +1,119 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"modelgate/internal/domain"
+)
+
+// ============================================================================
+// Scheduled usage export (source rows and checkpoints)
+// ============================================================================
+
+// ListUsageRecordsForExport returns every usage record created in [start, end), oldest first.
+// Rows soft-deleted by retention but not yet purged are included; they still describe real usage.
+func (s *TenantStore) ListUsageRecordsForExport(ctx context.Context, start, end time.Time) ([]*domain.UsageRecord, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT ur.id, ur.api_key_id, ak.name, ur.request_id, ur.model, ur.provider,
+			ur.input_tokens, ur.output_tokens, ur.total_tokens, ur.cost_usd, ur.latency_ms,
+			ur.is_success, ur.error_code, ur.error_message, ur.tool_calls, ur.thinking_tokens,
+			ur.metadata, ur.created_at
+		FROM usage_records ur
+		LEFT JOIN api_keys ak ON ur.api_key_id = ak.id
+		WHERE ur.created_at >= $1 AND ur.created_at < $2
+		ORDER BY ur.created_at
+	`, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []*domain.UsageRecord
+	for rows.Next() {
+		var record domain.UsageRecord
+		var apiKeyID, apiKeyName, requestID, errorCode, errorMessage sql.NullString
+		var metadata []byte
+		if err := rows.Scan(&record.ID, &apiKeyID, &apiKeyName, &requestID, &record.Model, &record.Provider,
+			&record.InputTokens, &record.OutputTokens, &record.TotalTokens, &record.CostUSD,
+			&record.LatencyMs, &record.Success, &errorCode, &errorMessage, &record.ToolCalls,
+			&record.ThinkingTokens, &metadata, &record.Timestamp); err != nil {
+			return nil, err
+		}
+		record.APIKeyID = apiKeyID.String
+		record.APIKeyName = apiKeyName.String
+		record.RequestID = requestID.String
+		record.ErrorCode = errorCode.String
+		record.ErrorMessage = errorMessage.String
+		if len(metadata) > 0 {
+			json.Unmarshal(metadata, &record.Metadata)
+		}
+		records = append(records, &record)
+	}
+	return records, rows.Err()
+}
+
+// EarliestUsageRecordTime returns when the oldest usage record was created, or nil if there are none
+func (s *TenantStore) EarliestUsageRecordTime(ctx context.Context) (*time.Time, error) {
+	var earliest sql.NullTime
+	if err := s.db.QueryRowContext(ctx, `SELECT MIN(created_at) FROM usage_records`).Scan(&earliest); err != nil {
+		return nil, err
+	}
+	if !earliest.Valid {
+		return nil, nil
+	}
+	return &earliest.Time, nil
+}
+
+// ListUsageExportStates returns the checkpoint of every dataset that has been exported
+func (s *TenantStore) ListUsageExportStates(ctx context.Context) (map[string]*domain.UsageExportState, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT dataset, exported_until, last_run_at, last_object_key, last_row_count, last_error, updated_at
+		FROM usage_exports
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	states := make(map[string]*domain.UsageExportState)
+	for rows.Next() {
+		var state domain.UsageExportState
+		var exportedUntil, lastRunAt sql.NullTime
+		var lastObjectKey, lastError sql.NullString
+		if err := rows.Scan(&state.Dataset, &exportedUntil, &lastRunAt, &lastObjectKey,
+			&state.LastRowCount, &lastError, &state.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if exportedUntil.Valid {
+			state.ExportedUntil = &exportedUntil.Time
+		}
+		if lastRunAt.Valid {
+			state.LastRunAt = &lastRunAt.Time
+		}
+		state.LastObjectKey = lastObjectKey.String
+		state.LastError = lastError.String
+		states[state.Dataset] = &state
+	}
+	return states, rows.Err()
+}
+
+// SaveUsageExportState stores the checkpoint and last result for a dataset
+func (s *TenantStore) SaveUsageExportState(ctx context.Context, state *domain.UsageExportState) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO usage_exports (dataset, exported_until, last_run_at, last_object_key, last_row_count, last_error)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (dataset) DO UPDATE SET
+			exported_until = EXCLUDED.exported_until,
+			last_run_at = EXCLUDED.last_run_at,
+			last_object_key = EXCLUDED.last_object_key,
+			last_row_count = EXCLUDED.last_row_count,
+			last_error = EXCLUDED.last_error
+	`, state.Dataset, state.ExportedUntil, state.LastRunAt,
+		sql.NullString{String: state.LastObjectKey, Valid: state.LastObjectKey != ""},
+		state.LastRowCount,
+		sql.NullString{String: state.LastError, Valid: state.LastError != ""})
+	return err
+}
@@ -0,0 +1,89 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"modelgate/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// ============================================================================
+// Personal API Keys
+// ============================================================================
+
+// CreateUserAPIKey creates a personal API key owned by a user. The key has no
+// role or group of its own; it uses the owner's API role (users.api_role_id).
+// A user's own role (users.role) is a dashboard role such as "member": it
+// names dashboard permissions and has no model, tool or budget policy to
+// enforce on API requests, so keys take the RBAC role assigned for API use.
+func (s *TenantStore) CreateUserAPIKey(ctx context.Context, userID, userEmail, name string, expiresAt *time.Time) (*domain.APIKey, string, error) {
+	fullKey, keyPrefix, keyHash := generateAPIKey()
+	id := uuid.New().String()
+	now := time.Now()
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO api_keys (id, name, key_prefix, key_hash, scopes, expires_at, owner_user_id, created_by, created_by_email, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, '[]', $5, $6, $6, $7, $8, $8)
+	`, id, name, keyPrefix, keyHash, expiresAt, userID, userEmail, now)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &domain.APIKey{
+		ID:             id,
+		Name:           name,
+		KeyPrefix:      keyPrefix,
+		KeyHash:        keyHash,
+		Scopes:         []string{},
+		OwnerUserID:    userID,
+		OwnerEmail:     userEmail,
+		CreatedBy:      userID,
+		CreatedByEmail: userEmail,
+		ExpiresAt:      expiresAt,
+		CreatedAt:      now,
+	}, fullKey, nil
+}
+
+// revokeUserAPIKeys revokes every active personal key of a user
+func revokeUserAPIKeys(ctx context.Context, tx Querier, userID, reason string) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE api_keys SET is_revoked = true, revoked_at = NOW(), revoked_reason = $2, updated_at = NOW()
+		WHERE owner_user_id = $1 AND is_revoked = false
+	`, userID, reason)
+	return err
+}
+
+// GetUsageStatsByUser gets the usage of personal API keys grouped by owner,
+// optionally for one project
+func (s *TenantStore) GetUsageStatsByUser(ctx context.Context, startTime, endTime time.Time, projectID string) ([]*domain.UserUsageStats, error) {
+	rows, err := s.analytics().QueryContext(ctx, `
+		SELECT
+			ur.user_id,
+			COALESCE(u.email, ''),
+			COUNT(*) as requests,
+			COALESCE(SUM(ur.input_tokens + ur.output_tokens), 0) as total_tokens,
+			COALESCE(SUM(ur.cost_usd), 0) as cost_usd
+		FROM usage_records ur
+		LEFT JOIN users u ON ur.user_id = u.id
+		WHERE ur.created_at >= $1 AND ur.created_at <= $2 AND ur.deleted_at IS NULL AND ur.user_id IS NOT NULL
+			AND ($3::uuid IS NULL OR ur.project_id = $3::uuid)
+		GROUP BY ur.user_id, u.email
+		ORDER BY cost_usd DESC
+	`, startTime, endTime, nullString(projectID))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []*domain.UserUsageStats
+	for rows.Next() {
+		var st domain.UserUsageStats
+		if err := rows.Scan(&st.UserID, &st.Email, &st.Requests, &st.TotalTokens, &st.CostUSD); err != nil {
+			return nil, err
+		}
+		stats = append(stats, &st)
+	}
+	return stats, rows.Err()
+}
@@ -0,0 +1,93 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"modelgate/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func TestPersonalAPIKeys(t *testing.T) {
+	s := testStore(t)
+	ctx := context.Background()
+
+	email := "keys-" + uuid.NewString()[:8] + "@example.com"
+	user, err := s.CreateUser(ctx, email, "Corr3ct-Horse-Battery", "Key Owner", "member", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		s.DB().ExecContext(ctx, "DELETE FROM usage_records WHERE user_id = $1", user.ID)
+		s.DeleteUser(ctx, user.ID)
+	})
+
+	key, secret, err := s.CreateUserAPIKey(ctx, user.ID, user.Email, "laptop", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.GetAPIKeyByHash(ctx, hashAPIKey(secret))
+	if err != nil || got == nil || got.OwnerUserID != user.ID || got.OwnerEmail != email {
+		t.Fatalf("expected the key of an active owner to authenticate, got %+v, %v", got, err)
+	}
+
+	// Usage of the key is attributed to its owner
+	now := time.Now()
+	if err := s.RecordUsage(ctx, &domain.UsageRecord{
+		APIKeyID: key.ID, RequestID: "req-" + uuid.NewString(), Model: "gpt-4o", Provider: domain.ProviderOpenAI,
+		InputTokens: 100, OutputTokens: 20, TotalTokens: 120, CostUSD: 0.01, Success: true, Timestamp: now,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	stats, err := s.GetUsageStatsByUser(ctx, now.Add(-time.Hour), now.Add(time.Hour), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var attributed *domain.UserUsageStats
+	for _, st := range stats {
+		if st.UserID == user.ID {
+			attributed = st
+		}
+	}
+	if attributed == nil || attributed.Requests != 1 || attributed.TotalTokens != 120 || attributed.Email != email {
+		t.Fatalf("expected the usage to be attributed to the owner, got %+v", attributed)
+	}
+
+	// The key stops working while its owner is inactive, even before it is revoked
+	if _, err := s.DB().ExecContext(ctx, "UPDATE users SET is_active = false WHERE id = $1", user.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := s.GetAPIKeyByHash(ctx, hashAPIKey(secret)); err != nil || got != nil {
+		t.Fatalf("expected the key of an inactive owner to be refused, got %+v, %v", got, err)
+	}
+	if _, err := s.DB().ExecContext(ctx, "UPDATE users SET is_active = true WHERE id = $1", user.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// Deactivating the owner revokes the key for good
+	inactive, active := false, true
+	if _, err := s.UpdateUser(ctx, user.ID, nil, nil, &inactive, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.UpdateUser(ctx, user.ID, nil, nil, &active, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if revoked, err := s.GetAPIKey(ctx, key.ID); err != nil || revoked == nil || !revoked.Revoked {
+		t.Fatalf("expected deactivating the owner to revoke the key, got %+v, %v", revoked, err)
+	}
+	if got, err := s.GetAPIKeyByHash(ctx, hashAPIKey(secret)); err != nil || got != nil {
+		t.Fatalf("expected a revoked key to be refused after reactivation, got %+v, %v", got, err)
+	}
+
+	// Keys that aren't personal are unaffected by the owner filter
+	roleKey, roleSecret, err := s.CreateAPIKey(ctx, "service-"+uuid.NewString()[:8], "", "", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.DB().ExecContext(ctx, "DELETE FROM api_keys WHERE id = $1", roleKey.ID) })
+	if got, err := s.GetAPIKeyByHash(ctx, hashAPIKey(roleSecret)); err != nil || got == nil {
+		t.Fatalf("expected a key without an owner to authenticate, got %+v, %v", got, err)
+	}
+}
@@ -0,0 +1,164 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"time"
+
+	"modelgate/internal/domain"
+
+	"github.com/lib/pq"
+	"github.com/pgvector/pgvector-go"
+)
+
+// vectorTypeDims matches a pgvector column type such as "vector(768)"
+var vectorTypeDims = regexp.MustCompile(`^vector\((\d+)\)$`)
+
+// toolVectorRecheck is how long the dimensions of the tool vector column are
+// cached. Another instance may resize the column when the tool search
+// embedder changes, or pgvector may be installed after startup.
+const toolVectorRecheck = 5 * time.Minute
+
+// annSearchCandidates is the HNSW candidate list (hnsw.ef_search) for tool
+// searches. The index returns that many nearest tools before the role filter
+// applies, so a list wider than the default of 40 over-fetches for roles that
+// can only see a few of them.
+const annSearchCandidates = 400
+
+// toolVectorDimensions returns the dimensions of mcp_tools.combined_embedding_vector,
+// or 0 when the column doesn't exist because pgvector isn't installed
+func (s *TenantStore) toolVectorDimensions(ctx context.Context) int {
+	if checked := s.shared.toolVectorCheckedAt.Load(); checked != 0 && time.Since(time.Unix(0, checked)) < toolVectorRecheck {
+		return int(s.shared.toolVectorDims.Load())
+	}
+
+	s.shared.toolVectorMu.Lock()
+	defer s.shared.toolVectorMu.Unlock()
+	if checked := s.shared.toolVectorCheckedAt.Load(); checked != 0 && time.Since(time.Unix(0, checked)) < toolVectorRecheck {
+		return int(s.shared.toolVectorDims.Load())
+	}
+
+	var colType string
+	err := s.pool.QueryRowContext(context.WithoutCancel(ctx), `
+		SELECT format_type(atttypid, atttypmod)
+		FROM pg_attribute
+		WHERE attrelid = 'mcp_tools'::regclass
+		  AND attname = 'combined_embedding_vector'
+		  AND NOT attisdropped
+	`).Scan(&colType)
+	dims := 0
+	if err != nil {
+		if s.shared.toolVectorCheckedAt.Load() == 0 {
+			slog.Info("pgvector tool index not available, MCP tool search runs in memory")
+		}
+	} else if m := vectorTypeDims.FindStringSubmatch(colType); m != nil {
+		dims, _ = strconv.Atoi(m[1])
+	}
+	s.shared.toolVectorDims.Store(int32(dims))
+	s.shared.toolVectorCheckedAt.Store(time.Now().UnixNano())
+	return dims
+}
+
+// resetToolVectorDimensions makes the next search read the column's
+// dimensions again, after a query against it failed
+func (s *TenantStore) resetToolVectorDimensions() {
+	s.shared.toolVectorCheckedAt.Store(0)
+}
+
+// updateToolVector keeps the indexed vector column in step with a tool's
+// combined embedding. Embeddings of other dimensions are left out of the
+// index and only found by the in-memory search.
+func (s *TenantStore) updateToolVector(ctx context.Context, toolID string, combined []float32) error {
+	dims := s.toolVectorDimensions(ctx)
+	if dims == 0 {
+		return nil
+	}
+	var vec any
+	if len(combined) == dims {
+		vec = pgvector.NewVector(combined)
+	}
+	_, err := s.db.ExecContext(ctx, `UPDATE mcp_tools SET combined_embedding_vector = $2 WHERE id = $1`, toolID, vec)
+	return err
+}
+
+// searchableByRole restricts a query over mcp_tools t to the tools a role
+// may search (ALLOW or SEARCH visibility); a NULL role matches every tool
+const searchableByRole = `($%[1]d::uuid IS NULL OR EXISTS (
+			SELECT 1 FROM mcp_tool_permissions p
+			WHERE p.tool_id = t.id AND p.role_id = $%[1]d::uuid AND p.visibility IN ('ALLOW', 'SEARCH')
+		))`
+
+// searchToolsByANN finds the tools closest to the query embedding that the
+// role may search, using the HNSW index, best first. The role filter is part
+// of the query so the limit counts only tools the role can see.
+func (s *TenantStore) searchToolsByANN(ctx context.Context, queryEmbedding []float32, roleID string, limit int) ([]*domain.MCPTool, []float64, error) {
+	var ids []string
+	scoreByID := make(map[string]float64)
+	err := s.InTx(ctx, func(tx *TenantStore) error {
+		if _, err := tx.db.ExecContext(ctx, `SELECT set_config('hnsw.ef_search', $1, true)`, strconv.Itoa(max(annSearchCandidates, limit))); err != nil {
+			return err
+		}
+		rows, err := tx.db.QueryContext(ctx, `
+			SELECT t.id, 1 - (t.combined_embedding_vector <=> $1) AS score
+			FROM mcp_tools t
+			WHERE t.combined_embedding_vector IS NOT NULL
+				AND t.is_deprecated = FALSE
+				AND `+fmt.Sprintf(searchableByRole, 3)+`
+			ORDER BY t.combined_embedding_vector <=> $1
+			LIMIT $2
+		`, pgvector.NewVector(queryEmbedding), limit, nullString(roleID))
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var id string
+			var score float64
+			if err := rows.Scan(&id, &score); err != nil {
+				return err
+			}
+			ids = append(ids, id)
+			scoreByID[id] = score
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		s.resetToolVectorDimensions()
+		return nil, nil, fmt.Errorf("failed to query tool vectors: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil, nil, nil
+	}
+
+	found, err := s.queryMCPTools(ctx, `
+		SELECT t.id, t.server_id, s.name as server_name,
+			t.name, t.description, t.category,
+			t.input_schema, t.output_schema, t.input_examples,
+			t.defer_loading, t.is_deprecated, t.deprecation_message, t.deprecated_at,
+			t.version, t.execution_count, t.last_executed_at, t.avg_execution_time_ms,
+			t.created_at, t.updated_at
+		FROM mcp_tools t
+		JOIN mcp_servers s ON t.server_id = s.id
+		WHERE t.id = ANY($1::uuid[])
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, nil, err
+	}
+	byID := make(map[string]*domain.MCPTool, len(found))
+	for _, tool := range found {
+		byID[tool.ID] = tool
+	}
+
+	tools := make([]*domain.MCPTool, 0, len(ids))
+	scores := make([]float64, 0, len(ids))
+	for _, id := range ids {
+		if tool, ok := byID[id]; ok {
+			tools = append(tools, tool)
+			scores = append(scores, scoreByID[id])
+		}
+	}
+	return tools, scores, nil
+}
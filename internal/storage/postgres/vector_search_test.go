@@ -0,0 +1,143 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"modelgate/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// axisEmbedding returns a vector pointing mostly along axis 0, tilted towards
+// axis 1 by tilt, so that smaller tilts are closer to the query
+func axisEmbedding(dims int, tilt float32) []float32 {
+	v := make([]float32, dims)
+	v[0] = 1
+	v[1] = tilt
+	return v
+}
+
+func TestSearchToolsByVectorFiltersByRole(t *testing.T) {
+	s := testStore(t)
+	ctx := context.Background()
+
+	dims := s.toolVectorDimensions(ctx)
+	if dims == 0 {
+		dims = 768
+	}
+
+	suffix := uuid.NewString()[:8]
+	server := &domain.MCPServer{
+		Name: "vector-" + suffix, Slug: "vector-" + suffix, ServerType: domain.MCPServerTypeSSE,
+		Endpoint: "http://localhost:9", AuthType: domain.MCPAuthNone, Status: domain.MCPStatusConnected,
+	}
+	if err := s.CreateMCPServer(ctx, server); err != nil {
+		t.Fatal(err)
+	}
+	role := &domain.Role{Name: "vector-search-" + suffix}
+	if err := s.CreateRole(ctx, role); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		s.DeleteMCPServer(ctx, server.ID)
+		s.DeleteRole(ctx, role.ID)
+	})
+
+	// Twenty hidden tools are all closer to the query than the two the role
+	// may search, so a filter applied after the limit would drop both
+	addTool := func(name string, tilt float32, visibility domain.MCPToolVisibility) *domain.MCPTool {
+		t.Helper()
+		tool := &domain.MCPTool{ServerID: server.ID, Name: name, Description: name}
+		if err := s.UpsertMCPTool(ctx, tool); err != nil {
+			t.Fatal(err)
+		}
+		emb := axisEmbedding(dims, tilt)
+		if err := s.UpdateToolEmbeddings(ctx, tool.ID, emb, emb, emb); err != nil {
+			t.Fatal(err)
+		}
+		if visibility != "" {
+			now := time.Now()
+			if err := s.SetMCPToolPermission(ctx, &domain.MCPToolPermission{
+				RoleID: role.ID, ServerID: server.ID, ToolID: tool.ID, Visibility: visibility,
+				DecidedBy: uuid.NewString(), DecidedAt: &now,
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return tool
+	}
+	for i := range 20 {
+		addTool(fmt.Sprintf("hidden_%d", i), 0.01*float32(i), "")
+	}
+	addTool("denied", 0.1, domain.MCPVisibilityDeny)
+	searchable := addTool("searchable", 0.5, domain.MCPVisibilitySearch)
+	allowed := addTool("allowed", 0.6, domain.MCPVisibilityAllow)
+
+	query := axisEmbedding(dims, 0)
+	check := func(t *testing.T) {
+		tools, scores, err := s.SearchToolsByVectorWithScores(ctx, query, role.ID, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(tools) != 2 || len(scores) != 2 || tools[0].ID != searchable.ID || tools[1].ID != allowed.ID {
+			t.Fatalf("expected the role's two searchable tools best first, got %v", toolNames(tools))
+		}
+		if scores[0] <= scores[1] {
+			t.Fatalf("expected scores in descending order, got %v", scores)
+		}
+
+		all, _, err := s.SearchToolsByVectorWithScores(ctx, query, "", 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(all) != 2 {
+			t.Fatalf("expected an unfiltered search to fill the limit, got %v", toolNames(all))
+		}
+	}
+
+	if s.toolVectorDimensions(ctx) > 0 {
+		t.Run("index", check)
+	}
+	t.Run("in memory", func(t *testing.T) {
+		// Cached dimensions that don't match the query force the in-memory search
+		s.shared.toolVectorDims.Store(int32(dims + 1))
+		s.shared.toolVectorCheckedAt.Store(time.Now().UnixNano())
+		t.Cleanup(s.resetToolVectorDimensions)
+		check(t)
+	})
+}
+
+func TestToolVectorDimensionsRefresh(t *testing.T) {
+	s := testStore(t)
+	ctx := context.Background()
+
+	dims := s.toolVectorDimensions(ctx)
+
+	// A value read before the column was resized is kept until the recheck interval
+	s.shared.toolVectorDims.Store(int32(dims + 1))
+	if got := s.toolVectorDimensions(ctx); got != dims+1 {
+		t.Fatalf("expected the cached dimensions within the recheck interval, got %d", got)
+	}
+
+	s.shared.toolVectorCheckedAt.Store(time.Now().Add(-toolVectorRecheck - time.Second).UnixNano())
+	if got := s.toolVectorDimensions(ctx); got != dims {
+		t.Fatalf("expected the dimensions to be read again after the interval, got %d want %d", got, dims)
+	}
+
+	s.shared.toolVectorDims.Store(int32(dims + 1))
+	s.resetToolVectorDimensions()
+	if got := s.toolVectorDimensions(ctx); got != dims {
+		t.Fatalf("expected a reset to read the dimensions again, got %d want %d", got, dims)
+	}
+}
+
+func toolNames(tools []*domain.MCPTool) []string {
+	names := make([]string, len(tools))
+	for i, tool := range tools {
+		names[i] = tool.Name
+	}
+	return names
+}
@@ -4,11 +4,14 @@ package telemetry
 import (
 	"context"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"modelgate/internal/domain"
 )
 
 // Metrics holds all Prometheus metrics for ModelGate
@@ -31,6 +34,10 @@ type Metrics struct {
 	ProviderErrors   *prometheus.CounterVec
 	ProviderLatency  *prometheus.HistogramVec
 
+	// Streaming metrics
+	TimeToFirstToken      *prometheus.HistogramVec
+	StreamTokensPerSecond *prometheus.HistogramVec
+
 	// Tool metrics
 	ToolCalls  *prometheus.CounterVec
 	ToolErrors *prometheus.CounterVec
@@ -59,6 +66,7 @@ type Metrics struct {
 	CacheCostSaved   *prometheus.CounterVec   // Cost saved via cache (USD)
 	CacheEntries     *prometheus.GaugeVec     // Number of cache entries per tenant
 	CacheLatency     *prometheus.HistogramVec // Cache lookup latency
+	CacheSimilarity  *prometheus.HistogramVec // Similarity score of cache hits
 
 	// NEW: Routing Metrics
 	RoutingDecisions   *prometheus.CounterVec // Routing decisions by strategy
@@ -66,10 +74,20 @@ type Metrics struct {
 	RoutingFailures    *prometheus.CounterVec // Routing failures by reason
 
 	// NEW: Resilience Metrics
-	CircuitBreakerState *prometheus.GaugeVec   // Circuit breaker state (0=closed, 1=half-open, 2=open)
-	RetryAttempts       *prometheus.CounterVec // Retry attempts by provider
-	FallbackInvocations *prometheus.CounterVec // Fallback chain invocations
-	FallbackSuccess     *prometheus.CounterVec // Successful fallback executions
+	CircuitBreakerState       *prometheus.GaugeVec   // Circuit breaker state (0=closed, 1=half-open, 2=open)
+	CircuitBreakerTransitions *prometheus.CounterVec // Circuit breaker state changes
+	RetryAttempts             *prometheus.CounterVec // Retry attempts by provider
+	FallbackInvocations       *prometheus.CounterVec // Fallback chain invocations
+	FallbackSuccess           *prometheus.CounterVec // Successful fallback executions
+	HedgeRequests             *prometheus.CounterVec // Hedge-eligible streams, by whether the hedge fired
+	HedgeOutcomes             *prometheus.CounterVec // Hedged stream wins and losses per provider
+	HedgeWastedCost           *prometheus.CounterVec // Estimated cost of cancelled hedge losers (USD)
+	RegionFailovers           *prometheus.CounterVec // Requests moved off a failing provider region
+	EmbeddingFallbacks        *prometheus.CounterVec // Embedding requests moved off a failing model to its fallback
+	StructuredOutputRetries   *prometheus.CounterVec // Structured output repair attempts, by validation failure
+	StructuredOutputResults   *prometheus.CounterVec // Structured output requests, by whether repair was needed
+	SpendForecastAlerts       *prometheus.CounterVec // Alerts for budgets forecast to be exceeded
+	ConversationBudgetRejects *prometheus.CounterVec // Turns refused for reaching a per-conversation ceiling
 
 	// NEW: Health Tracking Metrics
 	ProviderHealth      *prometheus.GaugeVec // Provider health score (0-1)
@@ -80,6 +98,22 @@ type Metrics struct {
 	APIKeyUsage      *prometheus.CounterVec // API key usage by provider
 	APIKeyHealth     *prometheus.GaugeVec   // API key health score
 	APIKeyRateLimits *prometheus.CounterVec // Rate limit hits by key
+	KeySelections    *prometheus.CounterVec // Provider keys chosen by the key selector, by reason
+	BurstCredits     *prometheus.CounterVec // Burst credits borrowed against the next rate limit window
+
+	// Upstream Provider Quota Metrics
+	ProviderQuotaRemaining *prometheus.GaugeVec // Requests/tokens left in the provider's rate limit window
+	ProviderQuotaLimit     *prometheus.GaugeVec // Size of the provider's rate limit window
+
+	// Dispatcher Concurrency Metrics
+	DispatcherInFlight          *prometheus.GaugeVec     // In-flight requests by scope (api_key, role) and role
+	DispatcherConcurrencyLimits *prometheus.CounterVec   // Requests rejected by a concurrency limit
+	DispatcherQueueWait         *prometheus.HistogramVec // Time requests spent queued, by priority band
+
+	// Provider Connection Pool Metrics
+	ProviderPoolActive *prometheus.GaugeVec   // Connections serving a request
+	ProviderPoolIdle   *prometheus.GaugeVec   // Open connections waiting for a request
+	ProviderPoolWaits  *prometheus.CounterVec // Requests that waited for a connection
 }
 
 // NewMetrics creates and registers all metrics
@@ -172,6 +206,24 @@ func NewMetrics(registry prometheus.Registerer) *Metrics {
 			[]string{"provider", "model"},
 		),
 
+		TimeToFirstToken: factory.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "modelgate_time_to_first_token_seconds",
+				Help:    "Time from a streamed request to its first token in seconds",
+				Buckets: []float64{0.05, 0.1, 0.25, 0.5, 0.75, 1, 1.5, 2, 3, 5, 10, 30},
+			},
+			[]string{"provider", "model"},
+		),
+
+		StreamTokensPerSecond: factory.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "modelgate_stream_tokens_per_second",
+				Help:    "Output tokens per second of streamed responses after the first token",
+				Buckets: []float64{5, 10, 20, 30, 50, 75, 100, 150, 200, 300, 500},
+			},
+			[]string{"provider", "model"},
+		),
+
 		ToolCalls: factory.NewCounterVec(
 			prometheus.CounterOpts{
 				Name: "modelgate_tool_calls_total",
@@ -264,7 +316,7 @@ func NewMetrics(registry prometheus.Registerer) *Metrics {
 				Name: "modelgate_cache_hits_total",
 				Help: "Total cache hits",
 			},
-			[]string{"model", "tenant_id", "role_id"},
+			[]string{"model", "provider", "role_id", "tenant_id"},
 		),
 
 		CacheMisses: factory.NewCounterVec(
@@ -272,7 +324,7 @@ func NewMetrics(registry prometheus.Registerer) *Metrics {
 				Name: "modelgate_cache_misses_total",
 				Help: "Total cache misses",
 			},
-			[]string{"model", "tenant_id", "role_id"},
+			[]string{"model", "provider", "role_id", "tenant_id"},
 		),
 
 		CacheTokensSaved: factory.NewCounterVec(
@@ -308,13 +360,22 @@ func NewMetrics(registry prometheus.Registerer) *Metrics {
 			[]string{"tenant_id", "hit"},
 		),
 
+		CacheSimilarity: factory.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "modelgate_cache_similarity",
+				Help:    "Similarity score of semantic cache hits (1.0 for exact matches)",
+				Buckets: []float64{0.8, 0.85, 0.9, 0.925, 0.95, 0.975, 0.99, 1},
+			},
+			[]string{"model", "provider", "role_id"},
+		),
+
 		// NEW: Routing Metrics
 		RoutingDecisions: factory.NewCounterVec(
 			prometheus.CounterOpts{
 				Name: "modelgate_routing_decisions_total",
-				Help: "Total routing decisions by strategy",
+				Help: "Total routing decisions by strategy and selected target",
 			},
-			[]string{"strategy", "tenant_id"},
+			[]string{"strategy", "model", "provider", "role_id"},
 		),
 
 		RoutingModelSwitch: factory.NewCounterVec(
@@ -342,6 +403,14 @@ func NewMetrics(registry prometheus.Registerer) *Metrics {
 			[]string{"provider", "tenant_id"},
 		),
 
+		CircuitBreakerTransitions: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "modelgate_circuit_breaker_transitions_total",
+				Help: "Total circuit breaker state changes",
+			},
+			[]string{"provider", "from", "to"},
+		),
+
 		RetryAttempts: factory.NewCounterVec(
 			prometheus.CounterOpts{
 				Name: "modelgate_retry_attempts_total",
@@ -366,6 +435,76 @@ func NewMetrics(registry prometheus.Registerer) *Metrics {
 			[]string{"fallback_provider", "tenant_id"},
 		),
 
+		HedgeRequests: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "modelgate_hedge_requests_total",
+				Help: "Total streams eligible for hedging, by whether the hedge request was sent",
+			},
+			[]string{"provider", "role_id", "hedged"},
+		),
+
+		HedgeOutcomes: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "modelgate_hedge_outcomes_total",
+				Help: "Total hedged streams won or lost, by provider and leg (primary or hedge)",
+			},
+			[]string{"provider", "leg", "outcome"},
+		),
+
+		HedgeWastedCost: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "modelgate_hedge_wasted_cost_usd_total",
+				Help: "Estimated cost in USD of hedged streams that lost the race and were cancelled",
+			},
+			[]string{"provider", "model"},
+		),
+
+		RegionFailovers: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "modelgate_region_failovers_total",
+				Help: "Total requests moved from a provider region that was down or throttled to the next region",
+			},
+			[]string{"provider", "region", "reason"},
+		),
+
+		EmbeddingFallbacks: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "modelgate_embedding_fallbacks_total",
+				Help: "Total embedding requests moved from a failing model to its next fallback",
+			},
+			[]string{"model", "fallback", "reason"},
+		),
+
+		StructuredOutputRetries: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "modelgate_structured_output_retries_total",
+				Help: "Total structured output repair attempts, by the validation failure that triggered them",
+			},
+			[]string{"provider", "model", "reason"},
+		),
+
+		StructuredOutputResults: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "modelgate_structured_output_results_total",
+				Help: "Total structured output requests, by outcome (valid, repaired or failed)",
+			},
+			[]string{"provider", "model", "outcome"},
+		),
+		SpendForecastAlerts: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "modelgate_spend_forecast_alerts_total",
+				Help: "Total alerts for budgets whose month-end spend forecast exceeds the limit",
+			},
+			[]string{"scope"},
+		),
+		ConversationBudgetRejects: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "modelgate_conversation_budget_rejections_total",
+				Help: "Total conversation turns refused for reaching a role's per-conversation token or cost ceiling",
+			},
+			[]string{"role_id", "limit"},
+		),
+
 		// NEW: Health Tracking Metrics
 		ProviderHealth: factory.NewGaugeVec(
 			prometheus.GaugeOpts{
@@ -415,6 +554,89 @@ func NewMetrics(registry prometheus.Registerer) *Metrics {
 			},
 			[]string{"provider", "key_name", "tenant_id"},
 		),
+
+		KeySelections: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "modelgate_key_selections_total",
+				Help: "Total provider keys chosen by the key selector, by reason",
+			},
+			[]string{"provider", "model", "key_id", "reason"},
+		),
+
+		BurstCredits: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "modelgate_rate_limit_burst_credits_total",
+				Help: "Total burst credits borrowed against the next rate limit window",
+			},
+			[]string{"kind"},
+		),
+
+		// Upstream Provider Quota Metrics
+		ProviderQuotaRemaining: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "modelgate_provider_quota_remaining",
+				Help: "Requests or tokens left in the provider's rate limit window, from response headers",
+			},
+			[]string{"provider", "key_id", "kind"},
+		),
+
+		ProviderQuotaLimit: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "modelgate_provider_quota_limit",
+				Help: "Size of the provider's rate limit window, from response headers",
+			},
+			[]string{"provider", "key_id", "kind"},
+		),
+
+		// Dispatcher Concurrency Metrics
+		DispatcherInFlight: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "modelgate_dispatcher_in_flight_requests",
+				Help: "Requests currently in flight in the dispatcher against API key or role limits, by role",
+			},
+			[]string{"scope", "role_id"},
+		),
+
+		DispatcherConcurrencyLimits: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "modelgate_dispatcher_concurrency_limited_total",
+				Help: "Total requests rejected by a per-API-key or per-role concurrency limit, by role",
+			},
+			[]string{"scope", "role_id"},
+		),
+
+		DispatcherQueueWait: factory.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "modelgate_dispatcher_queue_wait_seconds",
+				Help:    "Time requests spent in the dispatcher queue, by priority band",
+				Buckets: []float64{0.001, 0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+			},
+			[]string{"priority", "model", "provider", "role_id"},
+		),
+
+		ProviderPoolActive: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "modelgate_provider_pool_active_connections",
+				Help: "Provider connections serving a request",
+			},
+			[]string{"provider"},
+		),
+
+		ProviderPoolIdle: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "modelgate_provider_pool_idle_connections",
+				Help: "Open provider connections waiting for a request",
+			},
+			[]string{"provider"},
+		),
+
+		ProviderPoolWaits: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "modelgate_provider_pool_waits_total",
+				Help: "Requests that found every allowed provider connection busy and waited for one",
+			},
+			[]string{"provider"},
+		),
 	}
 }
 
@@ -462,6 +684,15 @@ func (r *RequestRecorder) RecordSuccess(inputTokens, outputTokens int64, costUSD
 	r.metrics.ProviderLatency.WithLabelValues(r.provider, r.model).Observe(duration)
 }
 
+// RecordStreamTiming records a streamed request's time to first token and,
+// once the stream finished, its throughput
+func (r *RequestRecorder) RecordStreamTiming(ttft time.Duration, tokensPerSecond float64) {
+	r.metrics.TimeToFirstToken.WithLabelValues(r.provider, r.model).Observe(ttft.Seconds())
+	if tokensPerSecond > 0 {
+		r.metrics.StreamTokensPerSecond.WithLabelValues(r.provider, r.model).Observe(tokensPerSecond)
+	}
+}
+
 // RecordError records a failed request
 func (r *RequestRecorder) RecordError(errorType string) {
 	duration := time.Since(r.startTime).Seconds()
@@ -543,9 +774,10 @@ func Init(cfg interface{}) (*Metrics, func(), error) {
 // NEW: Advanced Feature Metrics Recording Methods
 // ============================================================================
 
-// RecordCacheHit records a semantic cache hit
-func (m *Metrics) RecordCacheHit(model, tenantID, roleID string, tokensSaved int64, costSaved float64) {
-	m.CacheHits.WithLabelValues(model, tenantID, roleID).Inc()
+// RecordCacheHit records a semantic cache hit and how similar the cached prompt was
+func (m *Metrics) RecordCacheHit(model, provider, tenantID, roleID string, similarity float64, tokensSaved int64, costSaved float64) {
+	m.CacheHits.WithLabelValues(model, provider, roleID, tenantID).Inc()
+	m.CacheSimilarity.WithLabelValues(model, provider, roleID).Observe(similarity)
 	if tokensSaved > 0 {
 		m.CacheTokensSaved.WithLabelValues(model, tenantID).Add(float64(tokensSaved))
 	}
@@ -555,8 +787,8 @@ func (m *Metrics) RecordCacheHit(model, tenantID, roleID string, tokensSaved int
 }
 
 // RecordCacheMiss records a semantic cache miss
-func (m *Metrics) RecordCacheMiss(model, tenantID, roleID string) {
-	m.CacheMisses.WithLabelValues(model, tenantID, roleID).Inc()
+func (m *Metrics) RecordCacheMiss(model, provider, tenantID, roleID string) {
+	m.CacheMisses.WithLabelValues(model, provider, roleID, tenantID).Inc()
 }
 
 // RecordCacheLookup records cache lookup latency
@@ -573,9 +805,9 @@ func (m *Metrics) UpdateCacheEntries(tenantID string, count int) {
 	m.CacheEntries.WithLabelValues(tenantID).Set(float64(count))
 }
 
-// RecordRoutingDecision records a routing decision
-func (m *Metrics) RecordRoutingDecision(strategy, tenantID string) {
-	m.RoutingDecisions.WithLabelValues(strategy, tenantID).Inc()
+// RecordRoutingDecision records a routing decision and the model it selected
+func (m *Metrics) RecordRoutingDecision(strategy, model, provider, roleID string) {
+	m.RoutingDecisions.WithLabelValues(strategy, model, provider, roleID).Inc()
 }
 
 // RecordModelSwitch records when routing switches models
@@ -603,6 +835,13 @@ func (m *Metrics) UpdateCircuitBreakerState(provider, tenantID, state string) {
 	m.CircuitBreakerState.WithLabelValues(provider, tenantID).Set(stateValue)
 }
 
+// RecordCircuitBreakerTransition records a circuit breaker state change and
+// updates the state gauge
+func (m *Metrics) RecordCircuitBreakerTransition(provider, tenantID, from, to string) {
+	m.CircuitBreakerTransitions.WithLabelValues(provider, from, to).Inc()
+	m.UpdateCircuitBreakerState(provider, tenantID, to)
+}
+
 // RecordRetryAttempt records a retry attempt
 func (m *Metrics) RecordRetryAttempt(provider, tenantID, reason string) {
 	m.RetryAttempts.WithLabelValues(provider, tenantID, reason).Inc()
@@ -618,6 +857,58 @@ func (m *Metrics) RecordFallbackSuccess(fallbackProvider, tenantID string) {
 	m.FallbackSuccess.WithLabelValues(fallbackProvider, tenantID).Inc()
 }
 
+// RecordHedgeRequest records a hedge-eligible stream and whether its hedge fired
+func (m *Metrics) RecordHedgeRequest(provider, roleID string, hedged bool) {
+	m.HedgeRequests.WithLabelValues(provider, roleID, strconv.FormatBool(hedged)).Inc()
+}
+
+// RecordHedgeOutcome records which leg of a hedged stream answered first
+func (m *Metrics) RecordHedgeOutcome(provider, leg string, won bool) {
+	outcome := "loss"
+	if won {
+		outcome = "win"
+	}
+	m.HedgeOutcomes.WithLabelValues(provider, leg, outcome).Inc()
+}
+
+// RecordHedgeWaste records the estimated cost of a cancelled hedge loser
+func (m *Metrics) RecordHedgeWaste(provider, model string, costUSD float64) {
+	if costUSD > 0 {
+		m.HedgeWastedCost.WithLabelValues(provider, model).Add(costUSD)
+	}
+}
+
+// RecordRegionFailover records a request leaving a provider region for the next one
+func (m *Metrics) RecordRegionFailover(provider, region, reason string) {
+	m.RegionFailovers.WithLabelValues(provider, region, reason).Inc()
+}
+
+// RecordEmbeddingFallback records an embedding request leaving a failing model for its fallback
+func (m *Metrics) RecordEmbeddingFallback(model, fallback, reason string) {
+	m.EmbeddingFallbacks.WithLabelValues(model, fallback, reason).Inc()
+}
+
+// RecordStructuredOutputRetry records output sent back for repair after failing schema validation
+func (m *Metrics) RecordStructuredOutputRetry(provider, model, reason string) {
+	m.StructuredOutputRetries.WithLabelValues(provider, model, reason).Inc()
+}
+
+// RecordStructuredOutputResult records how a validated structured output request ended
+func (m *Metrics) RecordStructuredOutputResult(provider, model, outcome string) {
+	m.StructuredOutputResults.WithLabelValues(provider, model, outcome).Inc()
+}
+
+// RecordSpendForecastAlert records an alert for a budget forecast to be exceeded
+func (m *Metrics) RecordSpendForecastAlert(scope string) {
+	m.SpendForecastAlerts.WithLabelValues(scope).Inc()
+}
+
+// RecordConversationBudgetRejection records a turn refused for reaching a
+// per-conversation ceiling ("tokens" or "cost")
+func (m *Metrics) RecordConversationBudgetRejection(roleID, limit string) {
+	m.ConversationBudgetRejects.WithLabelValues(roleID, limit).Inc()
+}
+
 // UpdateProviderHealth updates provider health score
 func (m *Metrics) UpdateProviderHealth(provider, model, tenantID string, healthScore float64) {
 	m.ProviderHealth.WithLabelValues(provider, model, tenantID).Set(healthScore)
@@ -647,3 +938,53 @@ func (m *Metrics) UpdateAPIKeyHealth(provider, keyName, tenantID string, healthS
 func (m *Metrics) RecordAPIKeyRateLimit(provider, keyName, tenantID string) {
 	m.APIKeyRateLimits.WithLabelValues(provider, keyName, tenantID).Inc()
 }
+
+// RecordKeySelection records which provider key the key selector chose and why
+func (m *Metrics) RecordKeySelection(provider, model, keyID, reason string) {
+	m.KeySelections.WithLabelValues(provider, model, keyID, reason).Inc()
+}
+
+// RecordBurstCredits records burst credits borrowed for requests or tokens
+func (m *Metrics) RecordBurstCredits(kind string, credits int) {
+	m.BurstCredits.WithLabelValues(kind).Add(float64(credits))
+}
+
+// UpdateProviderQuota sets the remaining and limit gauges for the windows a
+// provider reported on a response
+func (m *Metrics) UpdateProviderQuota(provider, keyID string, q domain.ProviderQuota) {
+	for kind, w := range map[string]*domain.ProviderQuotaWindow{"requests": q.Requests, "tokens": q.Tokens} {
+		if w == nil {
+			continue
+		}
+		m.ProviderQuotaRemaining.WithLabelValues(provider, keyID, kind).Set(float64(w.Remaining))
+		if w.Limit > 0 {
+			m.ProviderQuotaLimit.WithLabelValues(provider, keyID, kind).Set(float64(w.Limit))
+		}
+	}
+}
+
+// AddDispatcherInFlight adjusts the in-flight request count of a scope and role by delta
+func (m *Metrics) AddDispatcherInFlight(scope, roleID string, delta int) {
+	m.DispatcherInFlight.WithLabelValues(scope, roleID).Add(float64(delta))
+}
+
+// RecordDispatcherConcurrencyLimit records a request rejected by a concurrency limit
+func (m *Metrics) RecordDispatcherConcurrencyLimit(scope, roleID string) {
+	m.DispatcherConcurrencyLimits.WithLabelValues(scope, roleID).Inc()
+}
+
+// RecordDispatcherQueueWait records how long a request waited in a priority band's queue
+func (m *Metrics) RecordDispatcherQueueWait(priority, model, provider, roleID string, wait time.Duration) {
+	m.DispatcherQueueWait.WithLabelValues(priority, model, provider, roleID).Observe(wait.Seconds())
+}
+
+// UpdateProviderPool sets the active and idle connection gauges of a provider
+func (m *Metrics) UpdateProviderPool(provider string, active, idle int) {
+	m.ProviderPoolActive.WithLabelValues(provider).Set(float64(active))
+	m.ProviderPoolIdle.WithLabelValues(provider).Set(float64(idle))
+}
+
+// RecordProviderPoolWait records a request that waited for a provider connection
+func (m *Metrics) RecordProviderPoolWait(provider string) {
+	m.ProviderPoolWaits.WithLabelValues(provider).Inc()
+}
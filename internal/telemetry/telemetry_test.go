@@ -0,0 +1,52 @@
+package telemetry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestFineGrainedMetrics(t *testing.T) {
+	m := NewMetrics(prometheus.NewRegistry())
+
+	m.RecordCacheHit("openai/gpt-4o", "openai", "", "support", 0.97, 120, 0.01)
+	m.RecordCacheMiss("openai/gpt-4o", "openai", "", "support")
+	if got := testutil.ToFloat64(m.CacheHits.WithLabelValues("openai/gpt-4o", "openai", "support", "")); got != 1 {
+		t.Fatalf("expected one cache hit, got %v", got)
+	}
+	if got := testutil.CollectAndCount(m.CacheSimilarity); got != 1 {
+		t.Fatalf("expected one similarity series, got %d", got)
+	}
+
+	m.RecordRoutingDecision("cost", "anthropic/claude-3-haiku", "anthropic", "support")
+	if got := testutil.ToFloat64(m.RoutingDecisions.WithLabelValues("cost", "anthropic/claude-3-haiku", "anthropic", "support")); got != 1 {
+		t.Fatalf("expected one routing decision, got %v", got)
+	}
+
+	m.RecordCircuitBreakerTransition("openai", "", "closed", "open")
+	if got := testutil.ToFloat64(m.CircuitBreakerState.WithLabelValues("openai", "")); got != 2 {
+		t.Fatalf("expected the open state gauge, got %v", got)
+	}
+
+	m.RecordKeySelection("openai", "openai/gpt-4o", "key-1", "round_robin")
+	m.RecordDispatcherQueueWait("high", "openai/gpt-4o", "openai", "support", 30*time.Millisecond)
+	if got := testutil.CollectAndCount(m.DispatcherQueueWait); got != 1 {
+		t.Fatalf("expected one queue wait series, got %d", got)
+	}
+}
+
+func TestStreamTimingMetrics(t *testing.T) {
+	m := NewMetrics(prometheus.NewRegistry())
+
+	r := m.NewRequestRecorder("chat_stream", "openai/gpt-4o", "", "openai")
+	r.RecordStreamTiming(400*time.Millisecond, 0) // Not finished yet: no throughput
+	r.RecordStreamTiming(300*time.Millisecond, 85)
+	if got := testutil.CollectAndCount(m.TimeToFirstToken); got != 1 {
+		t.Fatalf("expected one time to first token series, got %d", got)
+	}
+	if got := testutil.CollectAndCount(m.StreamTokensPerSecond); got != 1 {
+		t.Fatalf("expected one throughput series, got %d", got)
+	}
+}
@@ -0,0 +1,389 @@
+// Package tenantexport moves a whole tenant between ModelGate instances, for
+// disaster recovery and migrations. An export is a single archive of roles
+// with their policies, groups, API key metadata, provider settings and keys,
+// model configs, custom models and MCP servers with their tools. Secrets are
+// sealed with a passphrase given at export time rather than the source
+// instance's encryption key, and are encrypted again with the destination's
+// key when the archive is imported.
+package tenantexport
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"modelgate/internal/crypto"
+	"modelgate/internal/domain"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// FormatVersion is the archive format this package writes; archives of a
+// newer format are refused
+const FormatVersion = 1
+
+// Archive sections, one JSON file each
+const (
+	SectionRoles          = "roles.json"
+	SectionGroups         = "groups.json"
+	SectionProviders      = "providers.json"
+	SectionProviderKeys   = "provider_keys.json"
+	SectionAPIKeys        = "api_keys.json"
+	SectionCustomModels   = "custom_models.json"
+	SectionModelConfigs   = "model_configs.json"
+	SectionMCPServers     = "mcp_servers.json"
+	SectionMCPPermissions = "mcp_permissions.json"
+
+	manifestFile = "manifest.json"
+)
+
+// maxSectionSize bounds how much of an archive entry is read
+const maxSectionSize = 256 << 20
+
+var (
+	// ErrPassphraseRequired is returned when importing an archive with
+	// sealed secrets without its passphrase
+	ErrPassphraseRequired = errors.New("the archive's secrets are sealed; its passphrase is required")
+	// ErrWrongPassphrase is returned when the passphrase doesn't open the archive's secrets
+	ErrWrongPassphrase = errors.New("wrong passphrase for the archive's secrets")
+)
+
+// Manifest describes an archive
+type Manifest struct {
+	FormatVersion int                `json:"format_version"`
+	ExportedAt    time.Time          `json:"exported_at"`
+	Tenant        string             `json:"tenant"`
+	Sections      map[string]Section `json:"sections"`
+	Secrets       *Secrets           `json:"secrets,omitempty"` // nil when secrets were left out
+	Omitted       []string           `json:"omitted,omitempty"` // What the export left out, and why
+}
+
+// Section is the size and checksum of an archive section
+type Section struct {
+	Count  int    `json:"count"`
+	SHA256 string `json:"sha256"`
+}
+
+// Secrets describes how the archive's secrets are sealed: with a key derived
+// from the export passphrase with argon2id
+type Secrets struct {
+	KDF       string `json:"kdf"`
+	Salt      string `json:"salt"`
+	Time      uint32 `json:"time"`
+	MemoryKiB uint32 `json:"memory_kib"`
+	Threads   uint8  `json:"threads"`
+	Check     string `json:"check"` // A known value sealed with the key, to tell a wrong passphrase apart
+}
+
+// Key derivation parameters of new exports
+const (
+	kdfTime      = 3
+	kdfMemoryKiB = 64 * 1024
+	kdfThreads   = 4
+	checkValue   = "modelgate-tenant-export"
+)
+
+// Archive is the content of an export
+type Archive struct {
+	Manifest Manifest
+
+	// Roles, groups and providers are config sync entries (see configsync.Document)
+	Roles     []json.RawMessage
+	Groups    []json.RawMessage
+	Providers []json.RawMessage
+
+	ProviderKeys   []ProviderKey
+	APIKeys        []APIKey
+	CustomModels   []*domain.CustomModel
+	ModelConfigs   []*domain.ModelConfig
+	MCPServers     []MCPServer
+	MCPPermissions []MCPPermission
+}
+
+// ProviderKey is a provider API key; its credentials are sealed
+type ProviderKey struct {
+	Provider       domain.Provider `json:"provider"`
+	Name           string          `json:"name"`
+	Priority       int             `json:"priority"`
+	Enabled        bool            `json:"enabled"`
+	CredentialType string          `json:"credential_type"`
+	KeyPrefix      string          `json:"key_prefix"`
+	Sealed         string          `json:"sealed,omitempty"` // providerCredentials; empty when secrets were left out
+}
+
+// providerCredentials are the secrets of a provider key
+type providerCredentials struct {
+	APIKey          string `json:"api_key,omitempty"`
+	AccessKeyID     string `json:"access_key_id,omitempty"`
+	SecretAccessKey string `json:"secret_access_key,omitempty"`
+}
+
+// mcpAuthConfig is domain.MCPAuthConfig without its MarshalJSON, which masks
+// the secrets
+type mcpAuthConfig domain.MCPAuthConfig
+
+// APIKey is a gateway API key. The secret itself isn't known to the gateway;
+// its hash moves with the key, so clients keep using the secret they have.
+type APIKey struct {
+	Name           string               `json:"name"`
+	KeyPrefix      string               `json:"key_prefix"`
+	KeyHash        string               `json:"key_hash"`
+	Role           string               `json:"role,omitempty"`
+	Group          string               `json:"group,omitempty"`
+	Project        string               `json:"project,omitempty"` // Linked on import when a project of that name exists
+	Scopes         []string             `json:"scopes,omitempty"`
+	AllowedCIDRs   []string             `json:"allowed_cidrs,omitempty"`
+	Budget         *domain.APIKeyBudget `json:"budget,omitempty"`
+	ExpiresAt      *time.Time           `json:"expires_at,omitempty"`
+	CreatedByEmail string               `json:"created_by_email,omitempty"`
+	CreatedAt      time.Time            `json:"created_at"`
+}
+
+// MCPServer is an MCP server with its tools; its auth config is sealed
+type MCPServer struct {
+	Name                       string               `json:"name"`
+	Slug                       string               `json:"slug"`
+	Description                string               `json:"description,omitempty"`
+	ServerType                 domain.MCPServerType `json:"server_type"`
+	Endpoint                   string               `json:"endpoint"`
+	Arguments                  []string             `json:"arguments,omitempty"`
+	Environment                map[string]string    `json:"environment,omitempty"`
+	AuthType                   domain.MCPAuthType   `json:"auth_type"`
+	SealedAuth                 string               `json:"sealed_auth,omitempty"` // mcpAuthConfig
+	Version                    string               `json:"version,omitempty"`
+	AutoSync                   bool                 `json:"auto_sync"`
+	SyncIntervalMinutes        int                  `json:"sync_interval_minutes"`
+	HealthCheckIntervalSeconds int                  `json:"health_check_interval_seconds"`
+	Tags                       []string             `json:"tags,omitempty"`
+	Metadata                   map[string]string    `json:"metadata,omitempty"`
+	Tools                      []MCPTool            `json:"tools,omitempty"`
+}
+
+// MCPTool is a tool of an MCP server
+type MCPTool struct {
+	Name          string           `json:"name"`
+	Description   string           `json:"description,omitempty"`
+	Category      string           `json:"category,omitempty"`
+	InputSchema   map[string]any   `json:"input_schema"`
+	OutputSchema  map[string]any   `json:"output_schema,omitempty"`
+	InputExamples []map[string]any `json:"input_examples,omitempty"`
+	DeferLoading  bool             `json:"defer_loading"`
+	Version       string           `json:"version,omitempty"`
+}
+
+// MCPPermission is a role's visibility of an MCP tool, with tool, server and
+// role given by name
+type MCPPermission struct {
+	Role           string                   `json:"role"`
+	Server         string                   `json:"server"`
+	Tool           string                   `json:"tool"`
+	Visibility     domain.MCPToolVisibility `json:"visibility"`
+	DecidedBy      string                   `json:"decided_by,omitempty"`
+	DecidedByEmail string                   `json:"decided_by_email,omitempty"`
+	DecidedAt      *time.Time               `json:"decided_at,omitempty"`
+	DecisionReason string                   `json:"decision_reason,omitempty"`
+}
+
+// section pairs a section's file name with its content
+type section struct {
+	name  string
+	value any // Pointer to the slice
+	count int
+}
+
+func (a *Archive) sections() []section {
+	return []section{
+		{SectionRoles, &a.Roles, len(a.Roles)},
+		{SectionGroups, &a.Groups, len(a.Groups)},
+		{SectionProviders, &a.Providers, len(a.Providers)},
+		{SectionProviderKeys, &a.ProviderKeys, len(a.ProviderKeys)},
+		{SectionAPIKeys, &a.APIKeys, len(a.APIKeys)},
+		{SectionCustomModels, &a.CustomModels, len(a.CustomModels)},
+		{SectionModelConfigs, &a.ModelConfigs, len(a.ModelConfigs)},
+		{SectionMCPServers, &a.MCPServers, len(a.MCPServers)},
+		{SectionMCPPermissions, &a.MCPPermissions, len(a.MCPPermissions)},
+	}
+}
+
+// Write writes the archive as a gzipped tar of its manifest and sections,
+// filling in the manifest's section checksums
+func (a *Archive) Write(w io.Writer) error {
+	sections := a.sections()
+	files := make([][]byte, len(sections))
+	a.Manifest.Sections = make(map[string]Section, len(sections))
+	for i, s := range sections {
+		data, err := json.MarshalIndent(s.value, "", "  ")
+		if err != nil {
+			return fmt.Errorf("encode %s: %w", s.name, err)
+		}
+		sum := sha256.Sum256(data)
+		files[i] = data
+		a.Manifest.Sections[s.name] = Section{Count: s.count, SHA256: hex.EncodeToString(sum[:])}
+	}
+	manifest, err := json.MarshalIndent(a.Manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode manifest: %w", err)
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	modTime := a.Manifest.ExportedAt
+	writeFile := func(name string, data []byte) error {
+		hdr := &tar.Header{Name: name, Mode: 0o600, Size: int64(len(data)), ModTime: modTime, Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+	if err := writeFile(manifestFile, manifest); err != nil {
+		return err
+	}
+	for i, s := range sections {
+		if err := writeFile(s.name, files[i]); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// ReadArchive reads an archive written by Write, checking every section
+// against the manifest's checksum and count
+func ReadArchive(r io.Reader) (*Archive, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("read archive: %w", err)
+	}
+	defer gz.Close()
+
+	files := make(map[string][]byte)
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := io.ReadAll(io.LimitReader(tr, maxSectionSize+1))
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", hdr.Name, err)
+		}
+		if len(data) > maxSectionSize {
+			return nil, fmt.Errorf("read %s: larger than %d bytes", hdr.Name, maxSectionSize)
+		}
+		files[hdr.Name] = data
+	}
+
+	a := &Archive{}
+	data, ok := files[manifestFile]
+	if !ok {
+		return nil, errors.New("read archive: no manifest.json; not a tenant export")
+	}
+	if err := json.Unmarshal(data, &a.Manifest); err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+	if a.Manifest.FormatVersion < 1 || a.Manifest.FormatVersion > FormatVersion {
+		return nil, fmt.Errorf("archive format %d is not supported (this version reads up to %d)", a.Manifest.FormatVersion, FormatVersion)
+	}
+
+	for _, s := range a.sections() {
+		want, listed := a.Manifest.Sections[s.name]
+		data, present := files[s.name]
+		if !listed || !present {
+			return nil, fmt.Errorf("archive is incomplete: %s is missing", s.name)
+		}
+		sum := sha256.Sum256(data)
+		if hex.EncodeToString(sum[:]) != want.SHA256 {
+			return nil, fmt.Errorf("archive is corrupt: %s doesn't match its checksum", s.name)
+		}
+		if err := json.Unmarshal(data, s.value); err != nil {
+			return nil, fmt.Errorf("read %s: %w", s.name, err)
+		}
+	}
+	for _, s := range a.sections() {
+		if s.count != a.Manifest.Sections[s.name].Count {
+			return nil, fmt.Errorf("archive is corrupt: %s has %d entries, the manifest lists %d", s.name, s.count, a.Manifest.Sections[s.name].Count)
+		}
+	}
+	return a, nil
+}
+
+// newSecrets derives a sealing key from a passphrase with new parameters
+func newSecrets(passphrase string) (*Secrets, *crypto.EncryptionService, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, nil, err
+	}
+	s := &Secrets{
+		KDF:       "argon2id",
+		Salt:      base64.StdEncoding.EncodeToString(salt),
+		Time:      kdfTime,
+		MemoryKiB: kdfMemoryKiB,
+		Threads:   kdfThreads,
+	}
+	sealer, err := s.sealer(passphrase)
+	if err != nil {
+		return nil, nil, err
+	}
+	if s.Check, err = sealer.Encrypt(checkValue); err != nil {
+		return nil, nil, err
+	}
+	return s, sealer, nil
+}
+
+// open derives the sealing key of an archive and checks the passphrase
+func (s *Secrets) open(passphrase string) (*crypto.EncryptionService, error) {
+	if s.KDF != "argon2id" {
+		return nil, fmt.Errorf("unsupported key derivation %q", s.KDF)
+	}
+	sealer, err := s.sealer(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	if check, err := sealer.Decrypt(s.Check); err != nil || check != checkValue {
+		return nil, ErrWrongPassphrase
+	}
+	return sealer, nil
+}
+
+func (s *Secrets) sealer(passphrase string) (*crypto.EncryptionService, error) {
+	salt, err := base64.StdEncoding.DecodeString(s.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid salt: %w", err)
+	}
+	return crypto.NewEncryptionService(argon2.IDKey([]byte(passphrase), salt, s.Time, s.MemoryKiB, s.Threads, 32))
+}
+
+// seal encrypts a value's JSON form with the archive's key
+func seal(sealer *crypto.EncryptionService, v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return sealer.Encrypt(string(data))
+}
+
+// unseal decrypts a value sealed by seal
+func unseal(sealer *crypto.EncryptionService, sealed string, v any) error {
+	data, err := sealer.Decrypt(sealed)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(data), v)
+}
@@ -0,0 +1,296 @@
+package tenantexport
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"modelgate/internal/configsync"
+	"modelgate/internal/custommodels"
+	"modelgate/internal/domain"
+	"modelgate/internal/provider"
+)
+
+// Store is the persistence used by exports and imports (implemented by postgres.TenantStore)
+type Store interface {
+	configsync.Store
+	custommodels.Store
+
+	ListModelConfigs(ctx context.Context) ([]*domain.ModelConfig, error)
+	SaveModelConfig(ctx context.Context, config *domain.ModelConfig) error
+
+	ListAPIKeys(ctx context.Context) ([]*domain.APIKeyWithRole, error)
+	ListAPIKeyHashes(ctx context.Context) (map[string]string, error)
+	APIKeyHashExists(ctx context.Context, keyHash string) (bool, error)
+	ImportAPIKey(ctx context.Context, key *domain.APIKey) error
+	ListProjects(ctx context.Context) ([]*domain.Project, error)
+
+	ListMCPServers(ctx context.Context) ([]*domain.MCPServer, error)
+	CreateMCPServer(ctx context.Context, server *domain.MCPServer) error
+	UpdateMCPServer(ctx context.Context, server *domain.MCPServer) error
+	ListMCPTools(ctx context.Context, serverID string) ([]*domain.MCPTool, error)
+	UpsertMCPTool(ctx context.Context, tool *domain.MCPTool) error
+	ListMCPPermissions(ctx context.Context, roleID string) ([]*domain.MCPToolPermission, error)
+	SetMCPToolPermission(ctx context.Context, perm *domain.MCPToolPermission) error
+}
+
+// KeyStore holds provider API keys (implemented by provider.KeySelector).
+// Keys are returned decrypted with the instance's encryption key and
+// encrypted with it when stored.
+type KeyStore interface {
+	ListKeys(ctx context.Context, tenantSlug string, p domain.Provider) ([]*provider.ProviderAPIKey, error)
+	StoreKey(ctx context.Context, tenantSlug string, p domain.Provider, apiKey, name string, priority int, accessKeyID, secretAccessKey string) (string, error)
+	UpdateKey(ctx context.Context, tenantSlug, keyID, name string, priority int, enabled bool) error
+	DeleteKey(ctx context.Context, tenantSlug, keyID string) error
+}
+
+// Service exports and imports tenants
+type Service struct {
+	store  Store
+	keys   KeyStore
+	tenant string
+}
+
+// NewService creates a service for a tenant's store and provider keys
+func NewService(store Store, keys KeyStore, tenantSlug string) *Service {
+	return &Service{store: store, keys: keys, tenant: tenantSlug}
+}
+
+// ExportOptions control an export
+type ExportOptions struct {
+	// Passphrase seals provider key credentials and MCP auth configs. Without
+	// one they are left out, and provider keys aren't exported.
+	Passphrase string
+}
+
+// Export reads the tenant into an archive. Revoked API keys are left out, as
+// are personal keys, whose owners are dashboard users and not part of an
+// export; the manifest lists what was left out.
+func (s *Service) Export(ctx context.Context, opts ExportOptions) (*Archive, error) {
+	a := &Archive{Manifest: Manifest{
+		FormatVersion: FormatVersion,
+		ExportedAt:    time.Now().UTC(),
+		Tenant:        s.tenant,
+	}}
+	omit := func(format string, args ...any) {
+		a.Manifest.Omitted = append(a.Manifest.Omitted, fmt.Sprintf(format, args...))
+	}
+
+	var sealer sealerFunc
+	if opts.Passphrase != "" {
+		secrets, enc, err := newSecrets(opts.Passphrase)
+		if err != nil {
+			return nil, fmt.Errorf("derive export key: %w", err)
+		}
+		a.Manifest.Secrets = secrets
+		sealer = func(v any) (string, error) { return seal(enc, v) }
+	}
+
+	doc, err := configsync.NewSyncer(s.store).Export(ctx)
+	if err != nil {
+		return nil, err
+	}
+	a.Roles, a.Groups, a.Providers = doc.Roles, doc.Groups, doc.Providers
+
+	roles, err := s.store.ListRoles(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list roles: %w", err)
+	}
+	groups, err := s.store.ListGroups(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list groups: %w", err)
+	}
+	groupNames := make(map[string]string, len(groups))
+	for _, g := range groups {
+		groupNames[g.ID] = g.Name
+	}
+
+	if err := s.exportProviderKeys(ctx, a, sealer, omit); err != nil {
+		return nil, err
+	}
+	if err := s.exportAPIKeys(ctx, a, groupNames, omit); err != nil {
+		return nil, err
+	}
+
+	if a.CustomModels, err = s.store.ListCustomModels(ctx); err != nil {
+		return nil, fmt.Errorf("list custom models: %w", err)
+	}
+	if a.ModelConfigs, err = s.store.ListModelConfigs(ctx); err != nil {
+		return nil, fmt.Errorf("list model configs: %w", err)
+	}
+
+	if err := s.exportMCP(ctx, a, roles, sealer, omit); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// sealerFunc seals a secret for the archive; nil when secrets are left out
+type sealerFunc func(v any) (string, error)
+
+func (s *Service) exportProviderKeys(ctx context.Context, a *Archive, sealer sealerFunc, omit func(string, ...any)) error {
+	if s.keys == nil {
+		omit("provider keys: no key store")
+		return nil
+	}
+	if sealer == nil {
+		omit("provider keys: exported without a passphrase")
+		return nil
+	}
+	providers, err := s.store.ListProviderConfigs(ctx)
+	if err != nil {
+		return fmt.Errorf("list providers: %w", err)
+	}
+	for _, p := range providers {
+		keys, err := s.keys.ListKeys(ctx, s.tenant, p.Provider)
+		if err != nil {
+			return fmt.Errorf("list %s keys: %w", p.Provider, err)
+		}
+		for _, k := range keys {
+			creds := providerCredentials{
+				APIKey:          k.APIKeyDecrypted,
+				AccessKeyID:     k.AccessKeyIDDecrypted,
+				SecretAccessKey: k.SecretAccessKeyDecrypted,
+			}
+			if creds == (providerCredentials{}) {
+				omit("provider key %s/%s: its credentials could not be decrypted", p.Provider, k.Name)
+				continue
+			}
+			sealed, err := sealer(creds)
+			if err != nil {
+				return fmt.Errorf("seal %s key %q: %w", p.Provider, k.Name, err)
+			}
+			a.ProviderKeys = append(a.ProviderKeys, ProviderKey{
+				Provider:       p.Provider,
+				Name:           k.Name,
+				Priority:       k.Priority,
+				Enabled:        k.Enabled,
+				CredentialType: k.CredentialType,
+				KeyPrefix:      k.KeyPrefix,
+				Sealed:         sealed,
+			})
+		}
+	}
+	return nil
+}
+
+func (s *Service) exportAPIKeys(ctx context.Context, a *Archive, groupNames map[string]string, omit func(string, ...any)) error {
+	keys, err := s.store.ListAPIKeys(ctx)
+	if err != nil {
+		return fmt.Errorf("list API keys: %w", err)
+	}
+	hashes, err := s.store.ListAPIKeyHashes(ctx)
+	if err != nil {
+		return fmt.Errorf("list API key hashes: %w", err)
+	}
+	revoked, personal := 0, 0
+	for _, k := range keys {
+		switch {
+		case k.Revoked:
+			revoked++
+			continue
+		case k.OwnerUserID != "":
+			personal++
+			continue
+		}
+		a.APIKeys = append(a.APIKeys, APIKey{
+			Name:           k.Name,
+			KeyPrefix:      k.KeyPrefix,
+			KeyHash:        hashes[k.ID],
+			Role:           k.RoleName,
+			Group:          groupNames[k.GroupID],
+			Project:        k.ProjectName,
+			Scopes:         k.Scopes,
+			AllowedCIDRs:   k.AllowedCIDRs,
+			Budget:         k.Budget,
+			ExpiresAt:      k.ExpiresAt,
+			CreatedByEmail: k.CreatedByEmail,
+			CreatedAt:      k.CreatedAt,
+		})
+	}
+	if revoked > 0 {
+		omit("%d revoked API keys", revoked)
+	}
+	if personal > 0 {
+		omit("%d personal API keys, which belong to dashboard users", personal)
+	}
+	return nil
+}
+
+func (s *Service) exportMCP(ctx context.Context, a *Archive, roles []*domain.Role, sealer sealerFunc, omit func(string, ...any)) error {
+	servers, err := s.store.ListMCPServers(ctx)
+	if err != nil {
+		return fmt.Errorf("list MCP servers: %w", err)
+	}
+	serverNames := make(map[string]string, len(servers))
+	toolNames := make(map[string]string)
+	unsealed := 0
+	for _, srv := range servers {
+		serverNames[srv.ID] = srv.Name
+		entry := MCPServer{
+			Name:                       srv.Name,
+			Slug:                       srv.Slug,
+			Description:                srv.Description,
+			ServerType:                 srv.ServerType,
+			Endpoint:                   srv.Endpoint,
+			Arguments:                  srv.Arguments,
+			Environment:                srv.Environment,
+			AuthType:                   srv.AuthType,
+			Version:                    srv.Version,
+			AutoSync:                   srv.AutoSync,
+			SyncIntervalMinutes:        srv.SyncIntervalMinutes,
+			HealthCheckIntervalSeconds: srv.HealthCheckIntervalSeconds,
+			Tags:                       srv.Tags,
+			Metadata:                   srv.Metadata,
+		}
+		if srv.AuthType != "" && srv.AuthType != domain.MCPAuthNone {
+			if sealer == nil {
+				unsealed++
+			} else if entry.SealedAuth, err = sealer(mcpAuthConfig(srv.AuthConfig)); err != nil {
+				return fmt.Errorf("seal auth of MCP server %q: %w", srv.Name, err)
+			}
+		}
+
+		tools, err := s.store.ListMCPTools(ctx, srv.ID)
+		if err != nil {
+			return fmt.Errorf("list tools of MCP server %q: %w", srv.Name, err)
+		}
+		for _, t := range tools {
+			toolNames[t.ID] = t.Name
+			entry.Tools = append(entry.Tools, MCPTool{
+				Name:          t.Name,
+				Description:   t.Description,
+				Category:      t.Category,
+				InputSchema:   t.InputSchema,
+				OutputSchema:  t.OutputSchema,
+				InputExamples: t.InputExamples,
+				DeferLoading:  t.DeferLoading,
+				Version:       t.Version,
+			})
+		}
+		a.MCPServers = append(a.MCPServers, entry)
+	}
+	if unsealed > 0 {
+		omit("auth configs of %d MCP servers: exported without a passphrase", unsealed)
+	}
+
+	for _, r := range roles {
+		perms, err := s.store.ListMCPPermissions(ctx, r.ID)
+		if err != nil {
+			return fmt.Errorf("list MCP permissions of role %q: %w", r.Name, err)
+		}
+		for _, p := range perms {
+			a.MCPPermissions = append(a.MCPPermissions, MCPPermission{
+				Role:           r.Name,
+				Server:         serverNames[p.ServerID],
+				Tool:           toolNames[p.ToolID],
+				Visibility:     p.Visibility,
+				DecidedBy:      p.DecidedBy,
+				DecidedByEmail: p.DecidedByEmail,
+				DecidedAt:      p.DecidedAt,
+				DecisionReason: p.DecisionReason,
+			})
+		}
+	}
+	return nil
+}
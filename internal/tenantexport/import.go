@@ -0,0 +1,896 @@
+package tenantexport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"modelgate/internal/configsync"
+	"modelgate/internal/crypto"
+	"modelgate/internal/custommodels"
+	"modelgate/internal/domain"
+	"modelgate/internal/provider"
+)
+
+// Kinds of the changes an import makes besides config sync's roles, groups and providers
+const (
+	KindProviderKey   = "provider_key"
+	KindAPIKey        = "api_key"
+	KindCustomModel   = "custom_model"
+	KindModelConfig   = "model_config"
+	KindMCPServer     = "mcp_server"
+	KindMCPPermission = "mcp_permission"
+)
+
+// ConflictMode says what an import does with an entry that already exists
+// on the destination
+type ConflictMode string
+
+const (
+	ConflictFail      ConflictMode = "fail"      // Import nothing
+	ConflictSkip      ConflictMode = "skip"      // Keep the existing entry
+	ConflictOverwrite ConflictMode = "overwrite" // Replace the existing entry
+	ConflictRename    ConflictMode = "rename"    // Import under a new name; entries keyed by something else than a name are skipped
+)
+
+// ParseConflictMode parses a conflict mode; empty is ConflictFail
+func ParseConflictMode(s string) (ConflictMode, error) {
+	switch m := ConflictMode(strings.ToLower(s)); m {
+	case "":
+		return ConflictFail, nil
+	case ConflictFail, ConflictSkip, ConflictOverwrite, ConflictRename:
+		return m, nil
+	}
+	return "", fmt.Errorf("unknown conflict mode %q, expected fail, skip, overwrite or rename", s)
+}
+
+// ErrConflicts is returned by an import in ConflictFail mode that found
+// entries that already exist; Report.Conflicts lists them
+var ErrConflicts = errors.New("the archive conflicts with existing data")
+
+// ImportOptions control an import
+type ImportOptions struct {
+	Passphrase string // Opens the archive's sealed secrets
+	OnConflict ConflictMode
+	DryRun     bool // Report the changes without making them
+
+	// Recorded as the creator of new roles, groups and keys
+	ActorID    string
+	ActorEmail string
+}
+
+// Report is the outcome of an import
+type Report struct {
+	Changes   []configsync.Change
+	Conflicts []string // Entries that already existed, and what was done with them
+	Warnings  []string // What was not imported, or only partly
+	// Verification compares the database with the archive after the import;
+	// empty for a dry run
+	Verification []Check
+}
+
+// Check is the verification of an archive section
+type Check struct {
+	Section  string   `json:"section"`
+	Expected int      `json:"expected"` // Entries the import wrote
+	Verified int      `json:"verified"` // Entries found in the database as written
+	Problems []string `json:"problems,omitempty"`
+}
+
+// Verified reports whether every section was found as written
+func (r *Report) Verified() bool {
+	for _, c := range r.Verification {
+		if c.Verified != c.Expected || len(c.Problems) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Report) warn(format string, args ...any) {
+	r.Warnings = append(r.Warnings, fmt.Sprintf(format, args...))
+}
+
+// importPlan is an import worked out against the destination before anything is written
+type importPlan struct {
+	opts   ImportOptions
+	sealer *crypto.EncryptionService
+	report *Report
+
+	doc     configsync.Document
+	roles   map[string]string // Archive role name to destination role name
+	groups  map[string]string
+	servers map[string]string
+
+	steps  []importStep
+	checks []func(ctx context.Context, r *resolved) Check
+}
+
+// importStep is a planned change outside config sync and the write that makes it
+type importStep struct {
+	change configsync.Change
+	apply  func(ctx context.Context, r *resolved) (configsync.Change, error)
+}
+
+// resolved holds destination IDs by name, filled in as the import writes
+type resolved struct {
+	roles    map[string]string
+	groups   map[string]string
+	projects map[string]string
+	servers  map[string]string
+	tools    map[string]map[string]string // Server name to tool name to ID
+}
+
+// conflict records an existing entry and returns what to do with it
+func (p *importPlan) conflict(kind, name string, renamable bool) ConflictMode {
+	mode := p.opts.OnConflict
+	if mode == ConflictRename && !renamable {
+		mode = ConflictSkip
+	}
+	p.report.Conflicts = append(p.report.Conflicts, fmt.Sprintf("%s %q exists: %s", kind, name, mode))
+	return mode
+}
+
+// Import recreates the archive's tenant on this instance. Entries that
+// already exist are resolved with opts.OnConflict; everything is planned and
+// validated before the first write. After the import the database is read
+// back and compared with the archive (Report.Verification).
+func (s *Service) Import(ctx context.Context, a *Archive, opts ImportOptions) (*Report, error) {
+	if opts.OnConflict == "" {
+		opts.OnConflict = ConflictFail
+	}
+	p := &importPlan{opts: opts, report: &Report{}}
+	if a.Manifest.Secrets != nil {
+		if opts.Passphrase == "" {
+			return nil, ErrPassphraseRequired
+		}
+		var err error
+		if p.sealer, err = a.Manifest.Secrets.open(opts.Passphrase); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.planConfig(ctx, p, a); err != nil {
+		return nil, err
+	}
+	if err := s.planProviderKeys(ctx, p, a); err != nil {
+		return nil, err
+	}
+	if err := s.planModels(ctx, p, a); err != nil {
+		return nil, err
+	}
+	if err := s.planAPIKeys(ctx, p, a); err != nil {
+		return nil, err
+	}
+	if err := s.planMCP(ctx, p, a); err != nil {
+		return nil, err
+	}
+	if opts.OnConflict == ConflictFail && len(p.report.Conflicts) > 0 {
+		return p.report, ErrConflicts
+	}
+
+	// Roles, groups and providers are validated by a dry run of config sync
+	syncer := configsync.NewSyncer(s.store)
+	syncOpts := configsync.Options{DryRun: true, ActorID: opts.ActorID, ActorEmail: opts.ActorEmail}
+	syncChanges, err := syncer.Apply(ctx, &p.doc, syncOpts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.DryRun {
+		p.report.Changes = syncChanges
+		for _, st := range p.steps {
+			p.report.Changes = append(p.report.Changes, st.change)
+		}
+		return p.report, nil
+	}
+
+	syncOpts.DryRun = false
+	if p.report.Changes, err = syncer.Apply(ctx, &p.doc, syncOpts); err != nil {
+		return p.report, err
+	}
+	r, err := s.resolve(ctx)
+	if err != nil {
+		return p.report, err
+	}
+	for _, st := range p.steps {
+		change, err := st.apply(ctx, r)
+		if err != nil {
+			return p.report, fmt.Errorf("%s: %w", st.change, err)
+		}
+		p.report.Changes = append(p.report.Changes, change)
+	}
+
+	if p.report.Verification, err = s.verify(ctx, p); err != nil {
+		return p.report, fmt.Errorf("verify import: %w", err)
+	}
+	return p.report, nil
+}
+
+// resolve reads the destination IDs of roles, groups, projects and MCP servers by name
+func (s *Service) resolve(ctx context.Context) (*resolved, error) {
+	r := &resolved{
+		roles:    make(map[string]string),
+		groups:   make(map[string]string),
+		projects: make(map[string]string),
+		servers:  make(map[string]string),
+		tools:    make(map[string]map[string]string),
+	}
+	roles, err := s.store.ListRoles(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list roles: %w", err)
+	}
+	for _, role := range roles {
+		r.roles[role.Name] = role.ID
+	}
+	groups, err := s.store.ListGroups(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list groups: %w", err)
+	}
+	for _, g := range groups {
+		r.groups[g.Name] = g.ID
+	}
+	projects, err := s.store.ListProjects(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list projects: %w", err)
+	}
+	for _, pr := range projects {
+		r.projects[pr.Name] = pr.ID
+	}
+	servers, err := s.store.ListMCPServers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list MCP servers: %w", err)
+	}
+	for _, srv := range servers {
+		r.servers[srv.Name] = srv.ID
+	}
+	return r, nil
+}
+
+// uniqueName returns name with an "-imported" suffix that isn't taken
+func uniqueName(name string, taken func(string) bool) string {
+	candidate := name + "-imported"
+	for i := 2; taken(candidate); i++ {
+		candidate = name + "-imported-" + strconv.Itoa(i)
+	}
+	return candidate
+}
+
+// planConfig resolves the conflicts of roles, groups and providers and builds
+// the config sync document that imports them
+func (s *Service) planConfig(ctx context.Context, p *importPlan, a *Archive) error {
+	roles, err := s.store.ListRoles(ctx)
+	if err != nil {
+		return fmt.Errorf("list roles: %w", err)
+	}
+	groups, err := s.store.ListGroups(ctx)
+	if err != nil {
+		return fmt.Errorf("list groups: %w", err)
+	}
+	providers, err := s.store.ListProviderConfigs(ctx)
+	if err != nil {
+		return fmt.Errorf("list providers: %w", err)
+	}
+
+	existingRoles := make(map[string]bool, len(roles))
+	for _, r := range roles {
+		existingRoles[r.Name] = true
+	}
+	p.roles = make(map[string]string, len(a.Roles))
+	for _, raw := range a.Roles {
+		entry, name, err := decodeEntry(raw, "name")
+		if err != nil {
+			return fmt.Errorf("%s: %w", SectionRoles, err)
+		}
+		p.roles[name] = name
+		if existingRoles[name] {
+			switch p.conflict(configsync.KindRole, name, true) {
+			case ConflictSkip, ConflictFail:
+				continue
+			case ConflictRename:
+				renamed := uniqueName(name, func(n string) bool { return existingRoles[n] })
+				existingRoles[renamed] = true
+				p.roles[name] = renamed
+				// The destination's default role stays the default
+				entry["name"], entry["is_default"] = renamed, false
+			}
+		}
+		p.doc.Roles = append(p.doc.Roles, mustMarshal(entry))
+	}
+
+	existingGroups := make(map[string]bool, len(groups))
+	for _, g := range groups {
+		existingGroups[g.Name] = true
+	}
+	p.groups = make(map[string]string, len(a.Groups))
+	for _, raw := range a.Groups {
+		entry, name, err := decodeEntry(raw, "name")
+		if err != nil {
+			return fmt.Errorf("%s: %w", SectionGroups, err)
+		}
+		p.groups[name] = name
+		if existingGroups[name] {
+			switch p.conflict(configsync.KindGroup, name, true) {
+			case ConflictSkip, ConflictFail:
+				continue
+			case ConflictRename:
+				renamed := uniqueName(name, func(n string) bool { return existingGroups[n] })
+				existingGroups[renamed] = true
+				p.groups[name] = renamed
+				entry["name"] = renamed
+			}
+		}
+		if roleNames, ok := entry["roles"].([]any); ok {
+			for i, rn := range roleNames {
+				if s, ok := rn.(string); ok && p.roles[s] != "" {
+					roleNames[i] = p.roles[s]
+				}
+			}
+		}
+		p.doc.Groups = append(p.doc.Groups, mustMarshal(entry))
+	}
+
+	for _, raw := range a.Providers {
+		_, name, err := decodeEntry(raw, "provider")
+		if err != nil {
+			return fmt.Errorf("%s: %w", SectionProviders, err)
+		}
+		exists := slices.ContainsFunc(providers, func(c *domain.ProviderConfig) bool { return string(c.Provider) == name })
+		if exists {
+			if mode := p.conflict(configsync.KindProvider, name, false); mode != ConflictOverwrite {
+				continue
+			}
+		}
+		p.doc.Providers = append(p.doc.Providers, raw)
+	}
+
+	p.checks = append(p.checks, s.configCheck(p.doc))
+	return nil
+}
+
+// decodeEntry decodes a config sync entry and returns it with its key field
+func decodeEntry(raw json.RawMessage, keyField string) (map[string]any, string, error) {
+	var entry map[string]any
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		return nil, "", err
+	}
+	name, _ := entry[keyField].(string)
+	if name == "" {
+		return nil, "", fmt.Errorf("entry without %s", keyField)
+	}
+	return entry, name, nil
+}
+
+func (s *Service) planProviderKeys(ctx context.Context, p *importPlan, a *Archive) error {
+	if len(a.ProviderKeys) == 0 {
+		return nil
+	}
+	if s.keys == nil {
+		p.report.warn("%d provider keys not imported: no key store", len(a.ProviderKeys))
+		return nil
+	}
+	// Key IDs by provider and name; a provider's keys may share a name
+	existing := make(map[domain.Provider]map[string][]string)
+	var written []ProviderKey
+	for _, pk := range a.ProviderKeys {
+		if _, listed := existing[pk.Provider]; !listed {
+			keys, err := s.keys.ListKeys(ctx, s.tenant, pk.Provider)
+			if err != nil {
+				return fmt.Errorf("list %s keys: %w", pk.Provider, err)
+			}
+			existing[pk.Provider] = make(map[string][]string)
+			for _, k := range keys {
+				existing[pk.Provider][k.Name] = append(existing[pk.Provider][k.Name], k.ID)
+			}
+		}
+		var creds providerCredentials
+		if p.sealer == nil || unseal(p.sealer, pk.Sealed, &creds) != nil {
+			p.report.warn("provider key %s/%s not imported: its credentials could not be opened", pk.Provider, pk.Name)
+			continue
+		}
+
+		name := fmt.Sprintf("%s/%s", pk.Provider, pk.Name)
+		change := configsync.Change{Kind: KindProviderKey, Name: name, Action: configsync.ActionCreate}
+		names := existing[pk.Provider]
+		replaced := names[pk.Name]
+		if len(replaced) > 0 {
+			switch p.conflict(KindProviderKey, name, true) {
+			case ConflictSkip, ConflictFail:
+				continue
+			case ConflictOverwrite:
+				change.Action = configsync.ActionUpdate
+			case ConflictRename:
+				pk.Name = uniqueName(pk.Name, func(n string) bool { return names[n] != nil })
+				names[pk.Name] = []string{}
+				change.Name, replaced = fmt.Sprintf("%s/%s", pk.Provider, pk.Name), nil
+			}
+		}
+		written = append(written, pk)
+
+		p.steps = append(p.steps, importStep{change: change, apply: func(ctx context.Context, _ *resolved) (configsync.Change, error) {
+			for _, id := range replaced {
+				if err := s.keys.DeleteKey(ctx, s.tenant, id); err != nil {
+					return change, err
+				}
+			}
+			// Stored with the destination's encryption key
+			id, err := s.keys.StoreKey(ctx, s.tenant, pk.Provider, creds.APIKey, pk.Name, pk.Priority, creds.AccessKeyID, creds.SecretAccessKey)
+			if err != nil {
+				return change, err
+			}
+			if !pk.Enabled {
+				if err := s.keys.UpdateKey(ctx, s.tenant, id, pk.Name, pk.Priority, false); err != nil {
+					return change, err
+				}
+			}
+			change.ID = id
+			return change, nil
+		}})
+	}
+
+	p.checks = append(p.checks, func(ctx context.Context, _ *resolved) Check {
+		c := Check{Section: SectionProviderKeys, Expected: len(written)}
+		for _, pk := range written {
+			keys, err := s.keys.ListKeys(ctx, s.tenant, pk.Provider)
+			if err != nil {
+				c.Problems = append(c.Problems, fmt.Sprintf("list %s keys: %v", pk.Provider, err))
+				continue
+			}
+			i := slices.IndexFunc(keys, func(k *provider.ProviderAPIKey) bool { return k.Name == pk.Name })
+			switch {
+			case i < 0:
+				c.Problems = append(c.Problems, fmt.Sprintf("%s/%s is missing", pk.Provider, pk.Name))
+			case keys[i].Priority != pk.Priority || keys[i].Enabled != pk.Enabled:
+				c.Problems = append(c.Problems, fmt.Sprintf("%s/%s has different settings", pk.Provider, pk.Name))
+			default:
+				c.Verified++
+			}
+		}
+		return c
+	})
+	return nil
+}
+
+func (s *Service) planModels(ctx context.Context, p *importPlan, a *Archive) error {
+	customModels := custommodels.NewService(s.store)
+	existingCustom, err := s.store.ListCustomModels(ctx)
+	if err != nil {
+		return fmt.Errorf("list custom models: %w", err)
+	}
+	var writtenCustom []*domain.CustomModel
+	for _, m := range a.CustomModels {
+		m := *m
+		change := configsync.Change{Kind: KindCustomModel, Name: m.ModelID, Action: configsync.ActionCreate}
+		m.ID = ""
+		if i := slices.IndexFunc(existingCustom, func(e *domain.CustomModel) bool { return e.ModelID == m.ModelID }); i >= 0 {
+			if p.conflict(KindCustomModel, m.ModelID, false) != ConflictOverwrite {
+				continue
+			}
+			m.ID, change.ID, change.Action = existingCustom[i].ID, existingCustom[i].ID, configsync.ActionUpdate
+		}
+		writtenCustom = append(writtenCustom, &m)
+		p.steps = append(p.steps, importStep{change: change, apply: func(ctx context.Context, _ *resolved) (configsync.Change, error) {
+			if m.ID != "" {
+				return change, customModels.Update(ctx, &m)
+			}
+			if err := customModels.Register(ctx, &m); err != nil {
+				return change, err
+			}
+			change.ID = m.ID
+			return change, nil
+		}})
+	}
+
+	existingConfigs, err := s.store.ListModelConfigs(ctx)
+	if err != nil {
+		return fmt.Errorf("list model configs: %w", err)
+	}
+	var writtenConfigs []*domain.ModelConfig
+	for _, mc := range a.ModelConfigs {
+		mc := *mc
+		change := configsync.Change{Kind: KindModelConfig, Name: mc.ModelID, Action: configsync.ActionCreate}
+		mc.ID = ""
+		if i := slices.IndexFunc(existingConfigs, func(e *domain.ModelConfig) bool { return e.ModelID == mc.ModelID }); i >= 0 {
+			if p.conflict(KindModelConfig, mc.ModelID, false) != ConflictOverwrite {
+				continue
+			}
+			mc.ID, change.ID, change.Action = existingConfigs[i].ID, existingConfigs[i].ID, configsync.ActionUpdate
+		}
+		writtenConfigs = append(writtenConfigs, &mc)
+		p.steps = append(p.steps, importStep{change: change, apply: func(ctx context.Context, _ *resolved) (configsync.Change, error) {
+			if err := s.store.SaveModelConfig(ctx, &mc); err != nil {
+				return change, err
+			}
+			change.ID = mc.ID
+			return change, nil
+		}})
+	}
+
+	p.checks = append(p.checks, func(ctx context.Context, _ *resolved) Check {
+		c := Check{Section: SectionCustomModels, Expected: len(writtenCustom)}
+		current, err := s.store.ListCustomModels(ctx)
+		if err != nil {
+			c.Problems = append(c.Problems, err.Error())
+			return c
+		}
+		for _, m := range writtenCustom {
+			i := slices.IndexFunc(current, func(e *domain.CustomModel) bool { return e.ModelID == m.ModelID })
+			switch {
+			case i < 0:
+				c.Problems = append(c.Problems, fmt.Sprintf("%s is missing", m.ModelID))
+			case current[i].BaseURL != m.BaseURL || current[i].Upstream() != m.Upstream() || current[i].Enabled != m.Enabled:
+				c.Problems = append(c.Problems, fmt.Sprintf("%s has different settings", m.ModelID))
+			default:
+				c.Verified++
+			}
+		}
+		return c
+	}, func(ctx context.Context, _ *resolved) Check {
+		c := Check{Section: SectionModelConfigs, Expected: len(writtenConfigs)}
+		current, err := s.store.ListModelConfigs(ctx)
+		if err != nil {
+			c.Problems = append(c.Problems, err.Error())
+			return c
+		}
+		for _, mc := range writtenConfigs {
+			i := slices.IndexFunc(current, func(e *domain.ModelConfig) bool { return e.ModelID == mc.ModelID })
+			switch {
+			case i < 0:
+				c.Problems = append(c.Problems, fmt.Sprintf("%s is missing", mc.ModelID))
+			case current[i].IsEnabled != mc.IsEnabled || current[i].Alias != mc.Alias ||
+				current[i].MaxTokensOverride != mc.MaxTokensOverride || current[i].CostMultiplier != mc.CostMultiplier:
+				c.Problems = append(c.Problems, fmt.Sprintf("%s has different settings", mc.ModelID))
+			default:
+				c.Verified++
+			}
+		}
+		return c
+	})
+	return nil
+}
+
+func (s *Service) planAPIKeys(ctx context.Context, p *importPlan, a *Archive) error {
+	var written []APIKey
+	for _, k := range a.APIKeys {
+		exists, err := s.store.APIKeyHashExists(ctx, k.KeyHash)
+		if err != nil {
+			return fmt.Errorf("look up API key %q: %w", k.Name, err)
+		}
+		if exists {
+			// A key is its hash, so it can't be renamed or imported twice
+			p.conflict(KindAPIKey, k.Name, false)
+			continue
+		}
+		written = append(written, k)
+
+		k := k
+		roleName, groupName := p.roles[k.Role], p.groups[k.Group]
+		if roleName == "" {
+			roleName = k.Role
+		}
+		if groupName == "" {
+			groupName = k.Group
+		}
+		change := configsync.Change{Kind: KindAPIKey, Name: k.Name, Action: configsync.ActionCreate}
+		p.steps = append(p.steps, importStep{change: change, apply: func(ctx context.Context, r *resolved) (configsync.Change, error) {
+			key := &domain.APIKey{
+				Name:           k.Name,
+				KeyPrefix:      k.KeyPrefix,
+				KeyHash:        k.KeyHash,
+				Scopes:         k.Scopes,
+				AllowedCIDRs:   k.AllowedCIDRs,
+				Budget:         k.Budget,
+				ExpiresAt:      k.ExpiresAt,
+				CreatedByEmail: k.CreatedByEmail,
+			}
+			if roleName != "" {
+				if key.RoleID = r.roles[roleName]; key.RoleID == "" {
+					return change, fmt.Errorf("role %q does not exist", roleName)
+				}
+			}
+			if groupName != "" {
+				if key.GroupID = r.groups[groupName]; key.GroupID == "" {
+					return change, fmt.Errorf("group %q does not exist", groupName)
+				}
+			}
+			if k.Project != "" {
+				if key.ProjectID = r.projects[k.Project]; key.ProjectID == "" {
+					p.report.warn("API key %q: project %q does not exist; imported without it", k.Name, k.Project)
+				}
+			}
+			if err := s.store.ImportAPIKey(ctx, key); err != nil {
+				return change, err
+			}
+			change.ID = key.ID
+			return change, nil
+		}})
+	}
+
+	p.checks = append(p.checks, func(ctx context.Context, _ *resolved) Check {
+		c := Check{Section: SectionAPIKeys, Expected: len(written)}
+		for _, k := range written {
+			exists, err := s.store.APIKeyHashExists(ctx, k.KeyHash)
+			switch {
+			case err != nil:
+				c.Problems = append(c.Problems, fmt.Sprintf("%s: %v", k.Name, err))
+			case !exists:
+				c.Problems = append(c.Problems, fmt.Sprintf("%s is missing", k.Name))
+			default:
+				c.Verified++
+			}
+		}
+		return c
+	})
+	return nil
+}
+
+func (s *Service) planMCP(ctx context.Context, p *importPlan, a *Archive) error {
+	servers, err := s.store.ListMCPServers(ctx)
+	if err != nil {
+		return fmt.Errorf("list MCP servers: %w", err)
+	}
+	byName := make(map[string]*domain.MCPServer, len(servers))
+	slugs := make(map[string]bool, len(servers))
+	for _, srv := range servers {
+		byName[srv.Name] = srv
+		slugs[srv.Slug] = true
+	}
+
+	p.servers = make(map[string]string, len(a.MCPServers))
+	var written []MCPServer
+	for _, entry := range a.MCPServers {
+		archiveName := entry.Name
+		change := configsync.Change{Kind: KindMCPServer, Name: entry.Name, Action: configsync.ActionCreate}
+		var existingID string
+		if current, ok := byName[entry.Name]; ok {
+			switch p.conflict(KindMCPServer, entry.Name, true) {
+			case ConflictSkip, ConflictFail:
+				continue
+			case ConflictOverwrite:
+				existingID, change.ID, change.Action = current.ID, current.ID, configsync.ActionUpdate
+			case ConflictRename:
+				name := uniqueName(entry.Name, func(n string) bool { return byName[n] != nil })
+				byName[name] = &domain.MCPServer{}
+				change.Name = name
+				entry.Name = name
+			}
+		}
+		if existingID == "" && slugs[entry.Slug] {
+			entry.Slug = uniqueName(entry.Slug, func(n string) bool { return slugs[n] })
+		}
+		slugs[entry.Slug] = true
+		p.servers[archiveName] = entry.Name
+
+		server := &domain.MCPServer{
+			ID:                         existingID,
+			Name:                       entry.Name,
+			Slug:                       entry.Slug,
+			Description:                entry.Description,
+			ServerType:                 entry.ServerType,
+			Endpoint:                   entry.Endpoint,
+			Arguments:                  entry.Arguments,
+			Environment:                entry.Environment,
+			AuthType:                   entry.AuthType,
+			Version:                    entry.Version,
+			Status:                     domain.MCPStatusPending,
+			AutoSync:                   entry.AutoSync,
+			SyncIntervalMinutes:        entry.SyncIntervalMinutes,
+			HealthCheckIntervalSeconds: entry.HealthCheckIntervalSeconds,
+			Tags:                       entry.Tags,
+			Metadata:                   entry.Metadata,
+			CreatedBy:                  p.opts.ActorID,
+		}
+		if entry.SealedAuth != "" {
+			// Encrypted with the destination's key when stored
+			if p.sealer == nil || unseal(p.sealer, entry.SealedAuth, (*mcpAuthConfig)(&server.AuthConfig)) != nil {
+				p.report.warn("MCP server %q: its auth config could not be opened; set its credentials again", entry.Name)
+			}
+		} else if entry.AuthType != "" && entry.AuthType != domain.MCPAuthNone {
+			p.report.warn("MCP server %q: the archive has no credentials for it; set them again", entry.Name)
+		}
+		written = append(written, entry)
+
+		tools := entry.Tools
+		p.steps = append(p.steps, importStep{change: change, apply: func(ctx context.Context, r *resolved) (configsync.Change, error) {
+			if server.ID == "" {
+				if err := s.store.CreateMCPServer(ctx, server); err != nil {
+					return change, err
+				}
+			} else if err := s.store.UpdateMCPServer(ctx, server); err != nil {
+				return change, err
+			}
+			change.ID = server.ID
+			for _, t := range tools {
+				tool := &domain.MCPTool{
+					ServerID:      server.ID,
+					Name:          t.Name,
+					Description:   t.Description,
+					Category:      t.Category,
+					InputSchema:   t.InputSchema,
+					OutputSchema:  t.OutputSchema,
+					InputExamples: t.InputExamples,
+					DeferLoading:  t.DeferLoading,
+					Version:       t.Version,
+				}
+				if err := s.store.UpsertMCPTool(ctx, tool); err != nil {
+					return change, fmt.Errorf("tool %q: %w", t.Name, err)
+				}
+			}
+			r.servers[server.Name] = server.ID
+			return change, nil
+		}})
+	}
+	s.planMCPPermissions(p, a)
+
+	p.checks = append(p.checks, func(ctx context.Context, _ *resolved) Check {
+		c := Check{Section: SectionMCPServers, Expected: len(written)}
+		current, err := s.store.ListMCPServers(ctx)
+		if err != nil {
+			c.Problems = append(c.Problems, err.Error())
+			return c
+		}
+		for _, entry := range written {
+			i := slices.IndexFunc(current, func(srv *domain.MCPServer) bool { return srv.Name == entry.Name })
+			if i < 0 {
+				c.Problems = append(c.Problems, fmt.Sprintf("%s is missing", entry.Name))
+				continue
+			}
+			srv := current[i]
+			if srv.Endpoint != entry.Endpoint || srv.ServerType != entry.ServerType || srv.AuthType != entry.AuthType {
+				c.Problems = append(c.Problems, fmt.Sprintf("%s has different settings", entry.Name))
+				continue
+			}
+			tools, err := s.store.ListMCPTools(ctx, srv.ID)
+			if err != nil {
+				c.Problems = append(c.Problems, fmt.Sprintf("%s: %v", entry.Name, err))
+				continue
+			}
+			missing := 0
+			for _, t := range entry.Tools {
+				if !slices.ContainsFunc(tools, func(e *domain.MCPTool) bool { return e.Name == t.Name }) {
+					missing++
+				}
+			}
+			if missing > 0 {
+				c.Problems = append(c.Problems, fmt.Sprintf("%s is missing %d of its %d tools", entry.Name, missing, len(entry.Tools)))
+				continue
+			}
+			c.Verified++
+		}
+		return c
+	})
+	return nil
+}
+
+// planMCPPermissions plans the tool permissions of the roles and servers the
+// import writes; permissions of a role or server kept as it was are left alone
+func (s *Service) planMCPPermissions(p *importPlan, a *Archive) {
+	writtenRoles := make(map[string]bool)
+	for _, raw := range p.doc.Roles {
+		if _, name, err := decodeEntry(raw, "name"); err == nil {
+			writtenRoles[name] = true
+		}
+	}
+
+	var written []MCPPermission
+	for _, perm := range a.MCPPermissions {
+		role, server := p.roles[perm.Role], p.servers[perm.Server]
+		if !writtenRoles[role] || server == "" {
+			continue
+		}
+		perm.Role, perm.Server = role, server
+		written = append(written, perm)
+
+		name := fmt.Sprintf("%s: %s/%s", perm.Role, perm.Server, perm.Tool)
+		change := configsync.Change{Kind: KindMCPPermission, Name: name, Action: configsync.ActionUpdate}
+		p.steps = append(p.steps, importStep{change: change, apply: func(ctx context.Context, r *resolved) (configsync.Change, error) {
+			toolID, err := s.toolID(ctx, r, perm.Server, perm.Tool)
+			if err != nil {
+				return change, err
+			}
+			err = s.store.SetMCPToolPermission(ctx, &domain.MCPToolPermission{
+				RoleID:         r.roles[perm.Role],
+				ServerID:       r.servers[perm.Server],
+				ToolID:         toolID,
+				Visibility:     perm.Visibility,
+				DecidedBy:      perm.DecidedBy,
+				DecidedByEmail: perm.DecidedByEmail,
+				DecidedAt:      perm.DecidedAt,
+				DecisionReason: perm.DecisionReason,
+			})
+			return change, err
+		}})
+	}
+
+	p.checks = append(p.checks, func(ctx context.Context, r *resolved) Check {
+		c := Check{Section: SectionMCPPermissions, Expected: len(written)}
+		byRole := make(map[string][]*domain.MCPToolPermission)
+		for _, perm := range written {
+			name := fmt.Sprintf("%s: %s/%s", perm.Role, perm.Server, perm.Tool)
+			roleID := r.roles[perm.Role]
+			if _, listed := byRole[roleID]; !listed {
+				perms, err := s.store.ListMCPPermissions(ctx, roleID)
+				if err != nil {
+					c.Problems = append(c.Problems, fmt.Sprintf("%s: %v", name, err))
+					continue
+				}
+				byRole[roleID] = perms
+			}
+			toolID, _ := s.toolID(ctx, r, perm.Server, perm.Tool)
+			if !slices.ContainsFunc(byRole[roleID], func(e *domain.MCPToolPermission) bool {
+				return e.ToolID == toolID && e.Visibility == perm.Visibility
+			}) {
+				c.Problems = append(c.Problems, fmt.Sprintf("%s is missing", name))
+				continue
+			}
+			c.Verified++
+		}
+		return c
+	})
+}
+
+// toolID returns the ID of a tool of an imported server
+func (s *Service) toolID(ctx context.Context, r *resolved, server, tool string) (string, error) {
+	tools, ok := r.tools[server]
+	if !ok {
+		serverID := r.servers[server]
+		if serverID == "" {
+			return "", fmt.Errorf("MCP server %q does not exist", server)
+		}
+		list, err := s.store.ListMCPTools(ctx, serverID)
+		if err != nil {
+			return "", err
+		}
+		tools = make(map[string]string, len(list))
+		for _, t := range list {
+			tools[t.Name] = t.ID
+		}
+		r.tools[server] = tools
+	}
+	if tools[tool] == "" {
+		return "", fmt.Errorf("MCP tool %s/%s does not exist", server, tool)
+	}
+	return tools[tool], nil
+}
+
+// configCheck verifies roles, groups and providers with a dry run of config
+// sync: each change it would still make is a difference from the archive
+func (s *Service) configCheck(doc configsync.Document) func(ctx context.Context, _ *resolved) Check {
+	return func(ctx context.Context, _ *resolved) Check {
+		c := Check{Section: "roles, groups and providers", Expected: len(doc.Roles) + len(doc.Groups) + len(doc.Providers)}
+		changes, err := configsync.NewSyncer(s.store).Apply(ctx, &doc, configsync.Options{DryRun: true})
+		if err != nil {
+			c.Problems = append(c.Problems, err.Error())
+			return c
+		}
+		for _, ch := range changes {
+			c.Problems = append(c.Problems, fmt.Sprintf("%s differs: %s", ch.Kind, ch))
+		}
+		c.Verified = c.Expected - len(changes)
+		return c
+	}
+}
+
+// verify runs the plan's checks against the database
+func (s *Service) verify(ctx context.Context, p *importPlan) ([]Check, error) {
+	r, err := s.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	checks := make([]Check, 0, len(p.checks))
+	for _, check := range p.checks {
+		checks = append(checks, check(ctx, r))
+	}
+	return checks, nil
+}
+
+func mustMarshal(v any) json.RawMessage {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("tenantexport: marshal %T: %v", v, err))
+	}
+	return data
+}
@@ -0,0 +1,459 @@
+package tenantexport
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"modelgate/internal/configsync"
+	"modelgate/internal/domain"
+	"modelgate/internal/provider"
+)
+
+type fakeStore struct {
+	n            int
+	roles        []*domain.Role
+	policies     map[string]*domain.RolePolicy
+	groups       []*domain.Group
+	providers    map[domain.Provider]*domain.ProviderConfig
+	customModels []*domain.CustomModel
+	modelConfigs []*domain.ModelConfig
+	keys         []*domain.APIKey
+	projects     []*domain.Project
+	servers      []*domain.MCPServer
+	tools        []*domain.MCPTool
+	perms        []*domain.MCPToolPermission
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		policies:  make(map[string]*domain.RolePolicy),
+		providers: make(map[domain.Provider]*domain.ProviderConfig),
+	}
+}
+
+func (f *fakeStore) id() string {
+	f.n++
+	return fmt.Sprintf("id-%d", f.n)
+}
+
+func (f *fakeStore) ListRoles(ctx context.Context) ([]*domain.Role, error) { return f.roles, nil }
+
+func (f *fakeStore) GetRolePolicy(ctx context.Context, roleID string) (*domain.RolePolicy, error) {
+	if p, ok := f.policies[roleID]; ok {
+		copied := *p
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (f *fakeStore) CreateRole(ctx context.Context, role *domain.Role) error {
+	if role.ID == "" {
+		role.ID = f.id()
+	}
+	f.roles = append(f.roles, role)
+	return nil
+}
+
+func (f *fakeStore) UpdateRole(ctx context.Context, role *domain.Role) error {
+	for i, r := range f.roles {
+		if r.ID == role.ID {
+			f.roles[i] = role
+		}
+	}
+	return nil
+}
+
+func (f *fakeStore) DeleteRole(ctx context.Context, id string) error { return nil }
+
+func (f *fakeStore) UpdateRolePolicy(ctx context.Context, policy *domain.RolePolicy) error {
+	f.policies[policy.RoleID] = policy
+	return nil
+}
+
+func (f *fakeStore) ListGroups(ctx context.Context) ([]*domain.Group, error) { return f.groups, nil }
+
+func (f *fakeStore) CreateGroup(ctx context.Context, group *domain.Group) error {
+	if group.ID == "" {
+		group.ID = f.id()
+	}
+	f.groups = append(f.groups, group)
+	return nil
+}
+
+func (f *fakeStore) UpdateGroup(ctx context.Context, group *domain.Group) error { return nil }
+func (f *fakeStore) DeleteGroup(ctx context.Context, id string) error           { return nil }
+
+func (f *fakeStore) ListProviderConfigs(ctx context.Context) ([]*domain.ProviderConfig, error) {
+	var configs []*domain.ProviderConfig
+	for _, c := range f.providers {
+		copied := *c
+		configs = append(configs, &copied)
+	}
+	return configs, nil
+}
+
+func (f *fakeStore) GetProviderConfig(ctx context.Context, p domain.Provider) (*domain.ProviderConfig, error) {
+	return f.providers[p], nil
+}
+
+func (f *fakeStore) SaveProviderConfig(ctx context.Context, config *domain.ProviderConfig) error {
+	f.providers[config.Provider] = config
+	return nil
+}
+
+func (f *fakeStore) DeleteProviderModels(ctx context.Context, p string) error { return nil }
+func (f *fakeStore) SaveAvailableModels(ctx context.Context, p string, models []domain.ModelInfo) error {
+	return nil
+}
+
+func (f *fakeStore) CreateCustomModel(ctx context.Context, m *domain.CustomModel) error {
+	m.ID = f.id()
+	f.customModels = append(f.customModels, m)
+	return nil
+}
+
+func (f *fakeStore) GetCustomModel(ctx context.Context, id string) (*domain.CustomModel, error) {
+	for _, m := range f.customModels {
+		if m.ID == id {
+			return m, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeStore) ListCustomModels(ctx context.Context) ([]*domain.CustomModel, error) {
+	return f.customModels, nil
+}
+
+func (f *fakeStore) UpdateCustomModel(ctx context.Context, m *domain.CustomModel) error { return nil }
+func (f *fakeStore) DeleteCustomModel(ctx context.Context, id string) (bool, error) {
+	return false, nil
+}
+
+func (f *fakeStore) ListModelConfigs(ctx context.Context) ([]*domain.ModelConfig, error) {
+	return f.modelConfigs, nil
+}
+
+func (f *fakeStore) SaveModelConfig(ctx context.Context, config *domain.ModelConfig) error {
+	if config.ID == "" {
+		config.ID = f.id()
+	}
+	f.modelConfigs = append(f.modelConfigs, config)
+	return nil
+}
+
+func (f *fakeStore) ListAPIKeys(ctx context.Context) ([]*domain.APIKeyWithRole, error) {
+	var keys []*domain.APIKeyWithRole
+	for _, k := range f.keys {
+		withRole := &domain.APIKeyWithRole{APIKey: *k, RoleID: k.RoleID}
+		for _, r := range f.roles {
+			if r.ID == k.RoleID {
+				withRole.RoleName = r.Name
+			}
+		}
+		keys = append(keys, withRole)
+	}
+	return keys, nil
+}
+
+func (f *fakeStore) ListAPIKeyHashes(ctx context.Context) (map[string]string, error) {
+	hashes := make(map[string]string)
+	for _, k := range f.keys {
+		hashes[k.ID] = k.KeyHash
+	}
+	return hashes, nil
+}
+
+func (f *fakeStore) APIKeyHashExists(ctx context.Context, keyHash string) (bool, error) {
+	return slices.ContainsFunc(f.keys, func(k *domain.APIKey) bool { return k.KeyHash == keyHash }), nil
+}
+
+func (f *fakeStore) ImportAPIKey(ctx context.Context, key *domain.APIKey) error {
+	key.ID = f.id()
+	f.keys = append(f.keys, key)
+	return nil
+}
+
+func (f *fakeStore) ListProjects(ctx context.Context) ([]*domain.Project, error) {
+	return f.projects, nil
+}
+
+func (f *fakeStore) ListMCPServers(ctx context.Context) ([]*domain.MCPServer, error) {
+	return f.servers, nil
+}
+
+func (f *fakeStore) CreateMCPServer(ctx context.Context, server *domain.MCPServer) error {
+	server.ID = f.id()
+	f.servers = append(f.servers, server)
+	return nil
+}
+
+func (f *fakeStore) UpdateMCPServer(ctx context.Context, server *domain.MCPServer) error { return nil }
+
+func (f *fakeStore) ListMCPTools(ctx context.Context, serverID string) ([]*domain.MCPTool, error) {
+	var tools []*domain.MCPTool
+	for _, t := range f.tools {
+		if t.ServerID == serverID {
+			tools = append(tools, t)
+		}
+	}
+	return tools, nil
+}
+
+func (f *fakeStore) UpsertMCPTool(ctx context.Context, tool *domain.MCPTool) error {
+	tool.ID = f.id()
+	f.tools = append(f.tools, tool)
+	return nil
+}
+
+func (f *fakeStore) ListMCPPermissions(ctx context.Context, roleID string) ([]*domain.MCPToolPermission, error) {
+	var perms []*domain.MCPToolPermission
+	for _, p := range f.perms {
+		if p.RoleID == roleID {
+			perms = append(perms, p)
+		}
+	}
+	return perms, nil
+}
+
+func (f *fakeStore) SetMCPToolPermission(ctx context.Context, perm *domain.MCPToolPermission) error {
+	f.perms = append(f.perms, perm)
+	return nil
+}
+
+// fakeKeys holds provider keys in plain text, as the key selector returns them
+type fakeKeys struct {
+	keys []*provider.ProviderAPIKey
+}
+
+func (f *fakeKeys) ListKeys(ctx context.Context, tenantSlug string, p domain.Provider) ([]*provider.ProviderAPIKey, error) {
+	var keys []*provider.ProviderAPIKey
+	for _, k := range f.keys {
+		if k.Provider == p {
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
+
+func (f *fakeKeys) StoreKey(ctx context.Context, tenantSlug string, p domain.Provider, apiKey, name string, priority int, accessKeyID, secretAccessKey string) (string, error) {
+	id := fmt.Sprintf("pk-%d", len(f.keys)+1)
+	f.keys = append(f.keys, &provider.ProviderAPIKey{
+		ID: id, Provider: p, Name: name, Priority: priority, Enabled: true,
+		APIKeyDecrypted: apiKey, AccessKeyIDDecrypted: accessKeyID, SecretAccessKeyDecrypted: secretAccessKey,
+	})
+	return id, nil
+}
+
+func (f *fakeKeys) UpdateKey(ctx context.Context, tenantSlug, keyID, name string, priority int, enabled bool) error {
+	for _, k := range f.keys {
+		if k.ID == keyID {
+			k.Name, k.Priority, k.Enabled = name, priority, enabled
+		}
+	}
+	return nil
+}
+
+func (f *fakeKeys) DeleteKey(ctx context.Context, tenantSlug, keyID string) error {
+	f.keys = slices.DeleteFunc(f.keys, func(k *provider.ProviderAPIKey) bool { return k.ID == keyID })
+	return nil
+}
+
+// sourceTenant returns a tenant with one of everything an export covers
+func sourceTenant() (*fakeStore, *fakeKeys) {
+	s := newFakeStore()
+	s.roles = []*domain.Role{{ID: "r1", Name: "engineering", Description: "Engineers", Permissions: []string{"chat"}}}
+	s.policies["r1"] = &domain.RolePolicy{ID: "p1", RoleID: "r1", ModelRestriction: domain.ModelRestrictions{AllowedModels: []string{"gpt-4o"}}}
+	s.groups = []*domain.Group{{ID: "g1", Name: "platform", RoleIDs: []string{"r1"}}}
+	s.providers[domain.ProviderOpenAI] = &domain.ProviderConfig{Provider: domain.ProviderOpenAI, Enabled: true, BaseURL: "https://api.openai.com/v1"}
+	s.modelConfigs = []*domain.ModelConfig{{ID: "mc1", ModelID: "openai/gpt-4o", IsEnabled: true, Alias: "gpt", CostMultiplier: 1}}
+	s.keys = []*domain.APIKey{
+		{ID: "k1", Name: "ci", KeyPrefix: "sk-mg-ci", KeyHash: "hash-ci", RoleID: "r1", Scopes: []string{}},
+		{ID: "k2", Name: "old", KeyPrefix: "sk-mg-old", KeyHash: "hash-old", RoleID: "r1", Revoked: true},
+		{ID: "k3", Name: "mine", KeyPrefix: "sk-mg-me", KeyHash: "hash-me", OwnerUserID: "u1"},
+	}
+	s.servers = []*domain.MCPServer{{
+		ID: "s1", Name: "github", Slug: "github", ServerType: domain.MCPServerTypeSSE, Endpoint: "https://mcp.example.com",
+		AuthType: domain.MCPAuthBearer, AuthConfig: domain.MCPAuthConfig{BearerToken: "ghp_secret"},
+	}}
+	s.tools = []*domain.MCPTool{{ID: "t1", ServerID: "s1", Name: "create_issue", InputSchema: map[string]any{"type": "object"}}}
+	s.perms = []*domain.MCPToolPermission{{RoleID: "r1", ServerID: "s1", ToolID: "t1", Visibility: domain.MCPVisibilityAllow, DecidedBy: "u1"}}
+	keys := &fakeKeys{keys: []*provider.ProviderAPIKey{{ID: "pk-src", Provider: domain.ProviderOpenAI, Name: "primary", Priority: 1, Enabled: true, APIKeyDecrypted: "sk-openai-secret"}}}
+	return s, keys
+}
+
+// exportArchive exports a tenant and reads the archive back
+func exportArchive(t *testing.T, s *fakeStore, keys *fakeKeys, passphrase string) *Archive {
+	t.Helper()
+	a, err := NewService(s, keys, "default").Export(context.Background(), ExportOptions{Passphrase: passphrase})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := a.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadArchive(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return read
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	src, srcKeys := sourceTenant()
+	a := exportArchive(t, src, srcKeys, "correct horse")
+
+	if len(a.APIKeys) != 1 || a.APIKeys[0].KeyHash != "hash-ci" || a.APIKeys[0].Role != "engineering" {
+		t.Fatalf("unexpected API keys %+v", a.APIKeys)
+	}
+	if len(a.Manifest.Omitted) != 2 {
+		t.Fatalf("expected the revoked and personal keys to be listed as omitted, got %v", a.Manifest.Omitted)
+	}
+	if len(a.ProviderKeys) != 1 || strings.Contains(a.ProviderKeys[0].Sealed, "sk-openai-secret") ||
+		strings.Contains(a.MCPServers[0].SealedAuth, "ghp_secret") {
+		t.Fatal("expected secrets to be sealed")
+	}
+
+	dst, dstKeys := newFakeStore(), &fakeKeys{}
+	svc := NewService(dst, dstKeys, "default")
+	if _, err := svc.Import(ctx, a, ImportOptions{Passphrase: "wrong"}); !errors.Is(err, ErrWrongPassphrase) {
+		t.Fatalf("expected ErrWrongPassphrase, got %v", err)
+	}
+	report, err := svc.Import(ctx, a, ImportOptions{Passphrase: "correct horse"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Verified() {
+		t.Fatalf("verification failed: %+v", report.Verification)
+	}
+	if len(dst.roles) != 1 || len(dst.groups) != 1 || len(dst.keys) != 1 || len(dst.servers) != 1 || len(dst.perms) != 1 {
+		t.Fatalf("unexpected destination: %d roles, %d groups, %d keys, %d servers, %d permissions",
+			len(dst.roles), len(dst.groups), len(dst.keys), len(dst.servers), len(dst.perms))
+	}
+	if dst.keys[0].RoleID != dst.roles[0].ID || dst.groups[0].RoleIDs[0] != dst.roles[0].ID {
+		t.Fatal("expected the key and group to reference the imported role")
+	}
+	if dst.servers[0].AuthConfig.BearerToken != "ghp_secret" || dstKeys.keys[0].APIKeyDecrypted != "sk-openai-secret" {
+		t.Fatal("expected secrets to be opened and stored on the destination")
+	}
+	if p := dst.perms[0]; p.RoleID != dst.roles[0].ID || p.ToolID != dst.tools[0].ID {
+		t.Fatalf("unexpected permission %+v", p)
+	}
+}
+
+func TestImportConflicts(t *testing.T) {
+	ctx := context.Background()
+	src, srcKeys := sourceTenant()
+	a := exportArchive(t, src, srcKeys, "pw")
+
+	dst, dstKeys := newFakeStore(), &fakeKeys{}
+	svc := NewService(dst, dstKeys, "default")
+	if _, err := svc.Import(ctx, a, ImportOptions{Passphrase: "pw"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// By default an import into a tenant that has the entries changes nothing
+	report, err := svc.Import(ctx, a, ImportOptions{Passphrase: "pw"})
+	if !errors.Is(err, ErrConflicts) || len(report.Conflicts) == 0 || len(dst.roles) != 1 {
+		t.Fatalf("expected conflicts, got %v %+v", err, report)
+	}
+
+	report, err = svc.Import(ctx, a, ImportOptions{Passphrase: "pw", OnConflict: ConflictRename})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Verified() {
+		t.Fatalf("verification failed: %+v", report.Verification)
+	}
+	names := func() (roles, servers []string) {
+		for _, r := range dst.roles {
+			roles = append(roles, r.Name)
+		}
+		for _, s := range dst.servers {
+			servers = append(servers, s.Name)
+		}
+		return
+	}
+	roles, servers := names()
+	if !slices.Equal(roles, []string{"engineering", "engineering-imported"}) || !slices.Equal(servers, []string{"github", "github-imported"}) {
+		t.Fatalf("unexpected renames: %v %v", roles, servers)
+	}
+	// The key exists under its hash, so it's not imported twice
+	if len(dst.keys) != 1 {
+		t.Fatalf("expected the API key to be kept, got %d keys", len(dst.keys))
+	}
+	if !slices.ContainsFunc(report.Changes, func(c configsync.Change) bool {
+		return c.Kind == KindMCPPermission && c.Name == "engineering-imported: github-imported/create_issue"
+	}) {
+		t.Fatalf("expected the permission to follow the renames, got %v", report.Changes)
+	}
+}
+
+func TestArchiveIntegrity(t *testing.T) {
+	src, srcKeys := sourceTenant()
+	a, err := NewService(src, srcKeys, "default").Export(context.Background(), ExportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Manifest.Secrets != nil || len(a.ProviderKeys) != 0 || a.MCPServers[0].SealedAuth != "" {
+		t.Fatal("expected secrets to be left out without a passphrase")
+	}
+
+	var buf bytes.Buffer
+	if err := a.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadArchive(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("expected the archive to read back, got %v", err)
+	}
+
+	// A section changed after the export fails its checksum
+	tampered := rewriteEntry(t, buf.Bytes(), SectionRoles, []byte(`[{"name":"admin","permissions":["*"]}]`))
+	if _, err := ReadArchive(bytes.NewReader(tampered)); err == nil || !strings.Contains(err.Error(), "checksum") {
+		t.Fatalf("expected a checksum error, got %v", err)
+	}
+	if _, err := ReadArchive(strings.NewReader("not an archive")); err == nil {
+		t.Fatal("expected an error for a file that isn't an archive")
+	}
+}
+
+// rewriteEntry returns a copy of an archive with one file replaced
+func rewriteEntry(t *testing.T, archive []byte, name string, content []byte) []byte {
+	t.Helper()
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	gw := gzip.NewWriter(&out)
+	tr, tw := tar.NewReader(gz), tar.NewWriter(gw)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(tr)
+		if hdr.Name == name {
+			data = content
+		}
+		hdr.Size = int64(len(data))
+		tw.WriteHeader(hdr)
+		tw.Write(data)
+	}
+	tw.Close()
+	gw.Close()
+	return out.Bytes()
+}
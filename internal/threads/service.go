@@ -0,0 +1,231 @@
+// Package threads stores conversation history so clients can continue a
+// conversation by sending only the new turn.
+package threads
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+)
+
+// Errors returned by the service. HTTP handlers map these to status codes.
+var (
+	ErrThreadNotFound = errors.New("thread not found")
+	ErrThreadFull     = errors.New("thread has reached its size limit")
+	ErrInvalidMessage = errors.New("invalid message")
+)
+
+// Store is the persistence used by the service (implemented by postgres.TenantStore)
+type Store interface {
+	CreateThread(ctx context.Context, thread *domain.Thread, msgs []domain.Message) error
+	GetThread(ctx context.Context, id string) (*domain.Thread, error)
+	AppendThreadMessages(ctx context.Context, threadID string, msgs []domain.Message, maxMessages int, maxBytes int64, expiresAt time.Time) (bool, error)
+	ListThreadMessages(ctx context.Context, threadID string) ([]*domain.ThreadMessage, error)
+	DeleteThread(ctx context.Context, id string) (bool, error)
+	DeleteExpiredThreads(ctx context.Context, now time.Time) (int64, error)
+}
+
+// Service manages threads. A thread belongs to the API key that created it;
+// an empty apiKeyID (a session caller) can access any thread.
+type Service struct {
+	config config.ThreadsConfig
+	store  Store
+}
+
+// NewService creates a new threads service
+func NewService(cfg config.ThreadsConfig, store Store) *Service {
+	return &Service{config: cfg, store: store}
+}
+
+// Create opens a new thread, optionally seeded with messages
+func (s *Service) Create(ctx context.Context, apiKeyID string, msgs []domain.Message, metadata map[string]string) (*domain.Thread, error) {
+	if err := validateMessages(msgs); err != nil {
+		return nil, err
+	}
+	if err := s.checkRoom(&domain.Thread{}, msgs, 0); err != nil {
+		return nil, err
+	}
+
+	thread := &domain.Thread{
+		APIKeyID:  apiKeyID,
+		Metadata:  metadata,
+		ExpiresAt: s.expiry(),
+	}
+	if err := s.store.CreateThread(ctx, thread, msgs); err != nil {
+		return nil, fmt.Errorf("create thread: %w", err)
+	}
+	return thread, nil
+}
+
+// Get returns a thread the caller can access
+func (s *Service) Get(ctx context.Context, id, apiKeyID string) (*domain.Thread, error) {
+	thread, err := s.store.GetThread(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("get thread: %w", err)
+	}
+	// Expired threads are hidden even if the janitor has not removed them yet
+	if thread == nil || time.Now().After(thread.ExpiresAt) ||
+		(apiKeyID != "" && thread.APIKeyID != "" && thread.APIKeyID != apiKeyID) {
+		return nil, ErrThreadNotFound
+	}
+	return thread, nil
+}
+
+// Delete removes a thread and its messages
+func (s *Service) Delete(ctx context.Context, id, apiKeyID string) error {
+	if _, err := s.Get(ctx, id, apiKeyID); err != nil {
+		return err
+	}
+	deleted, err := s.store.DeleteThread(ctx, id)
+	if err != nil {
+		return fmt.Errorf("delete thread: %w", err)
+	}
+	if !deleted {
+		return ErrThreadNotFound
+	}
+	return nil
+}
+
+// Messages returns every message in a thread, oldest first
+func (s *Service) Messages(ctx context.Context, id, apiKeyID string) ([]*domain.ThreadMessage, error) {
+	if _, err := s.Get(ctx, id, apiKeyID); err != nil {
+		return nil, err
+	}
+	msgs, err := s.store.ListThreadMessages(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("list thread messages: %w", err)
+	}
+	return msgs, nil
+}
+
+// Append adds messages to the end of a thread and extends its expiry
+func (s *Service) Append(ctx context.Context, id, apiKeyID string, msgs []domain.Message) (*domain.Thread, error) {
+	if len(msgs) == 0 {
+		return nil, fmt.Errorf("%w: at least one message is required", ErrInvalidMessage)
+	}
+	if err := validateMessages(msgs); err != nil {
+		return nil, err
+	}
+	if _, err := s.Get(ctx, id, apiKeyID); err != nil {
+		return nil, err
+	}
+
+	ok, err := s.store.AppendThreadMessages(ctx, id, msgs, s.config.MaxMessages, s.config.MaxBytes, s.expiry())
+	if err != nil {
+		return nil, fmt.Errorf("append thread messages: %w", err)
+	}
+	if !ok {
+		// Either a limit was hit or the thread was deleted in the meantime
+		if _, err := s.Get(ctx, id, apiKeyID); err != nil {
+			return nil, err
+		}
+		return nil, ErrThreadFull
+	}
+	return s.Get(ctx, id, apiKeyID)
+}
+
+// History returns the stored messages to send ahead of a new turn. It fails
+// with ErrThreadFull if the turn and a reply would not fit in the thread, so
+// the request is rejected before it reaches a provider.
+func (s *Service) History(ctx context.Context, id, apiKeyID string, turn []domain.Message) ([]domain.Message, error) {
+	if err := validateMessages(turn); err != nil {
+		return nil, err
+	}
+	thread, err := s.Get(ctx, id, apiKeyID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkRoom(thread, turn, 1); err != nil {
+		return nil, err
+	}
+
+	stored, err := s.store.ListThreadMessages(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("list thread messages: %w", err)
+	}
+	history := make([]domain.Message, len(stored))
+	for i, m := range stored {
+		history[i] = m.Message
+	}
+	return history, nil
+}
+
+// CleanupExpired deletes threads that have not been used within the TTL
+func (s *Service) CleanupExpired(ctx context.Context) (int64, error) {
+	return s.store.DeleteExpiredThreads(ctx, time.Now())
+}
+
+// StartJanitor periodically deletes expired threads until ctx is cancelled
+func (s *Service) StartJanitor(ctx context.Context) {
+	interval := s.config.CleanupInterval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				n, err := s.CleanupExpired(ctx)
+				if err != nil {
+					slog.Error("Failed to clean up expired threads", "error", err)
+				} else if n > 0 {
+					slog.Info("Cleaned up expired threads", "count", n)
+				}
+			}
+		}
+	}()
+}
+
+// checkRoom reports ErrThreadFull if adding msgs plus reserved further
+// messages would exceed the message limit, or msgs would exceed the size limit
+func (s *Service) checkRoom(thread *domain.Thread, msgs []domain.Message, reserved int) error {
+	if max := s.config.MaxMessages; max > 0 && thread.MessageCount+len(msgs)+reserved > max {
+		return fmt.Errorf("%w: a thread can hold at most %d messages", ErrThreadFull, max)
+	}
+	if max := s.config.MaxBytes; max > 0 && thread.SizeBytes+messagesSize(msgs) > max {
+		return fmt.Errorf("%w: a thread can hold at most %d bytes", ErrThreadFull, max)
+	}
+	return nil
+}
+
+func (s *Service) expiry() time.Time {
+	ttl := s.config.TTL
+	if ttl <= 0 {
+		ttl = 30 * 24 * time.Hour
+	}
+	return time.Now().Add(ttl)
+}
+
+// validateMessages rejects roles that are not kept in threads. System prompts
+// are sent with each request rather than stored.
+func validateMessages(msgs []domain.Message) error {
+	for i, m := range msgs {
+		switch m.Role {
+		case "user", "assistant", "tool":
+		default:
+			return fmt.Errorf("%w: message %d has role %q; threads hold user, assistant and tool messages", ErrInvalidMessage, i, m.Role)
+		}
+	}
+	return nil
+}
+
+// messagesSize is the stored size of msgs, matching what the store counts
+func messagesSize(msgs []domain.Message) int64 {
+	var size int64
+	for _, m := range msgs {
+		b, _ := json.Marshal(m)
+		size += int64(len(b))
+	}
+	return size
+}
@@ -0,0 +1,128 @@
+package threads
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+)
+
+// memStore is an in-memory Store for tests
+type memStore struct {
+	threads  map[string]*domain.Thread
+	messages map[string][]*domain.ThreadMessage
+}
+
+func newMemStore() *memStore {
+	return &memStore{threads: map[string]*domain.Thread{}, messages: map[string][]*domain.ThreadMessage{}}
+}
+
+func (m *memStore) CreateThread(_ context.Context, thread *domain.Thread, msgs []domain.Message) error {
+	thread.ID = "thread-" + strconv.Itoa(len(m.threads)+1)
+	copied := *thread
+	m.threads[thread.ID] = &copied
+	m.add(thread.ID, msgs)
+	thread.MessageCount = copied.MessageCount
+	return nil
+}
+
+func (m *memStore) GetThread(_ context.Context, id string) (*domain.Thread, error) {
+	if t, ok := m.threads[id]; ok {
+		copied := *t
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (m *memStore) AppendThreadMessages(_ context.Context, threadID string, msgs []domain.Message, maxMessages int, maxBytes int64, expiresAt time.Time) (bool, error) {
+	t, ok := m.threads[threadID]
+	if !ok || (maxMessages > 0 && t.MessageCount+len(msgs) > maxMessages) ||
+		(maxBytes > 0 && t.SizeBytes+messagesSize(msgs) > maxBytes) {
+		return false, nil
+	}
+	t.ExpiresAt = expiresAt
+	m.add(threadID, msgs)
+	return true, nil
+}
+
+func (m *memStore) ListThreadMessages(_ context.Context, threadID string) ([]*domain.ThreadMessage, error) {
+	return m.messages[threadID], nil
+}
+
+func (m *memStore) DeleteThread(_ context.Context, id string) (bool, error) {
+	_, ok := m.threads[id]
+	delete(m.threads, id)
+	delete(m.messages, id)
+	return ok, nil
+}
+
+func (m *memStore) DeleteExpiredThreads(_ context.Context, now time.Time) (int64, error) {
+	var n int64
+	for id, t := range m.threads {
+		if t.ExpiresAt.Before(now) {
+			delete(m.threads, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *memStore) add(threadID string, msgs []domain.Message) {
+	t := m.threads[threadID]
+	for _, msg := range msgs {
+		t.MessageCount++
+		m.messages[threadID] = append(m.messages[threadID], &domain.ThreadMessage{ThreadID: threadID, Seq: t.MessageCount, Message: msg})
+	}
+	t.SizeBytes += messagesSize(msgs)
+}
+
+func text(role, s string) domain.Message {
+	return domain.Message{Role: role, Content: []domain.ContentBlock{{Type: "text", Text: s}}}
+}
+
+func TestHistoryAndLimits(t *testing.T) {
+	ctx := context.Background()
+	store := newMemStore()
+	svc := NewService(config.ThreadsConfig{MaxMessages: 4, TTL: time.Hour}, store)
+
+	thread, err := svc.Create(ctx, "key-a", []domain.Message{text("user", "hi"), text("assistant", "hello")}, nil)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := svc.Create(ctx, "key-a", []domain.Message{text("system", "be brief")}, nil); !errors.Is(err, ErrInvalidMessage) {
+		t.Fatalf("expected system messages to be rejected, got %v", err)
+	}
+
+	// Another key cannot see the thread; a session caller can
+	if _, err := svc.History(ctx, thread.ID, "key-b", nil); !errors.Is(err, ErrThreadNotFound) {
+		t.Fatalf("expected other keys to get not found, got %v", err)
+	}
+	history, err := svc.History(ctx, thread.ID, "", []domain.Message{text("user", "again")})
+	if err != nil || len(history) != 2 || history[1].Content[0].Text != "hello" {
+		t.Fatalf("expected the stored history, got %v %v", history, err)
+	}
+
+	// Two stored messages, one new turn and a reserved reply fit; a longer turn does not
+	if _, err := svc.History(ctx, thread.ID, "key-a", []domain.Message{text("user", "a"), text("user", "b")}); !errors.Is(err, ErrThreadFull) {
+		t.Fatalf("expected ErrThreadFull, got %v", err)
+	}
+	if _, err := svc.Append(ctx, thread.ID, "key-a", []domain.Message{text("user", "again"), text("assistant", "sure")}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	if _, err := svc.Append(ctx, thread.ID, "key-a", []domain.Message{text("user", "more")}); !errors.Is(err, ErrThreadFull) {
+		t.Fatalf("expected ErrThreadFull once the thread is at its limit, got %v", err)
+	}
+
+	// Expired threads are hidden and then removed
+	store.threads[thread.ID].ExpiresAt = time.Now().Add(-time.Minute)
+	if _, err := svc.Get(ctx, thread.ID, "key-a"); !errors.Is(err, ErrThreadNotFound) {
+		t.Fatalf("expected an expired thread to be hidden, got %v", err)
+	}
+	if n, _ := svc.CleanupExpired(ctx); n != 1 {
+		t.Fatalf("expected 1 expired thread removed, got %d", n)
+	}
+}
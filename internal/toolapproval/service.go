@@ -0,0 +1,222 @@
+// Package toolapproval queues MCP tools a role calls without permission for
+// admin review. Tools are denied to a role until allowed; instead of a silent
+// denial, the first call opens a pending request, admins are notified, and
+// approving the request grants the role the tool.
+package toolapproval
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+	"modelgate/internal/email"
+)
+
+var (
+	ErrNotFound        = errors.New("tool approval request not found")
+	ErrAlreadyDecided  = errors.New("tool approval request has already been decided")
+	ErrReasonRequired  = errors.New("a reason is required to deny a tool")
+	ErrInvalidGrant    = errors.New("approved tools must be granted ALLOW or SEARCH visibility")
+	ErrReviewerMissing = errors.New("a signed-in reviewer is required to decide tool approvals")
+)
+
+// Store is the persistence used by the service (implemented by postgres.TenantStore)
+type Store interface {
+	RequestMCPToolApproval(ctx context.Context, roleID, serverID, toolID, apiKeyID string) (*domain.MCPToolApproval, error)
+	GetMCPToolApproval(ctx context.Context, id string) (*domain.MCPToolApproval, error)
+	ListMCPToolApprovals(ctx context.Context, status string) ([]*domain.MCPToolApproval, error)
+	DecideMCPToolApproval(ctx context.Context, id, status string, visibility domain.MCPToolVisibility, decidedBy, decidedByEmail, reason string) (bool, error)
+	SettleMCPToolApproval(ctx context.Context, perm *domain.MCPToolPermission) error
+	SetMCPToolPermission(ctx context.Context, perm *domain.MCPToolPermission) error
+	ListAdminEmails(ctx context.Context) ([]string, error)
+}
+
+// Service runs the tool approval workflow
+type Service struct {
+	store      Store
+	cfg        config.ToolApprovalConfig
+	sender     email.Sender // nil when email is disabled
+	httpClient *http.Client
+}
+
+// NewService creates a tool approval service. Without a sender, notifications
+// go only to the configured webhook.
+func NewService(store Store, cfg config.ToolApprovalConfig, sender email.Sender) *Service {
+	return &Service{
+		store:      store,
+		cfg:        cfg,
+		sender:     sender,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Enabled reports whether calls to unpermitted tools are queued for review
+func (s *Service) Enabled() bool {
+	return s.cfg.Enabled
+}
+
+// Request records a role's call to a tool it has no permission for and
+// returns the role's request for the tool. Admins are notified when the
+// request is opened.
+func (s *Service) Request(ctx context.Context, roleID, apiKeyID string, server *domain.MCPServer, tool *domain.MCPTool) (*domain.MCPToolApproval, error) {
+	a, err := s.store.RequestMCPToolApproval(ctx, roleID, server.ID, tool.ID, apiKeyID)
+	if err != nil {
+		return nil, fmt.Errorf("request tool approval: %w", err)
+	}
+	if a.Status == domain.MCPApprovalPending && a.RequestCount == 1 {
+		s.notifyAdmins(ctx, a)
+		s.postWebhook(ctx, "mcp_tool_approval.requested", a)
+	}
+	return a, nil
+}
+
+// List returns approval requests, oldest first. An empty status returns all of them.
+func (s *Service) List(ctx context.Context, status string) ([]*domain.MCPToolApproval, error) {
+	return s.store.ListMCPToolApprovals(ctx, status)
+}
+
+// Approve grants the role of a pending request the tool with visibility
+// (ALLOW when empty). reviewerID must be the deciding user's ID.
+func (s *Service) Approve(ctx context.Context, id string, visibility domain.MCPToolVisibility, reason, reviewerID, reviewerEmail string) (*domain.MCPToolApproval, error) {
+	if visibility == "" {
+		visibility = domain.MCPVisibilityAllow
+	}
+	if visibility != domain.MCPVisibilityAllow && visibility != domain.MCPVisibilitySearch {
+		return nil, ErrInvalidGrant
+	}
+	if reviewerID == "" {
+		return nil, ErrReviewerMissing
+	}
+	a, err := s.decide(ctx, id, domain.MCPApprovalApproved, visibility, reason, reviewerID, reviewerEmail)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.SetMCPToolPermission(ctx, &domain.MCPToolPermission{
+		RoleID:         a.RoleID,
+		ServerID:       a.ServerID,
+		ToolID:         a.ToolID,
+		Visibility:     visibility,
+		DecidedBy:      reviewerID,
+		DecidedByEmail: reviewerEmail,
+		DecidedAt:      a.DecidedAt,
+		DecisionReason: reason,
+	}); err != nil {
+		return nil, fmt.Errorf("grant tool permission: %w", err)
+	}
+	s.postWebhook(ctx, "mcp_tool_approval.approved", a)
+	return a, nil
+}
+
+// Deny refuses a pending request. Later calls by the role get the reason.
+func (s *Service) Deny(ctx context.Context, id, reason, reviewerID, reviewerEmail string) (*domain.MCPToolApproval, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, ErrReasonRequired
+	}
+	a, err := s.decide(ctx, id, domain.MCPApprovalDenied, "", reason, reviewerID, reviewerEmail)
+	if err != nil {
+		return nil, err
+	}
+	s.postWebhook(ctx, "mcp_tool_approval.denied", a)
+	return a, nil
+}
+
+// Settle closes the role's pending request for a tool whose permission an
+// admin set directly, so it leaves the queue
+func (s *Service) Settle(ctx context.Context, perm *domain.MCPToolPermission) {
+	if err := s.store.SettleMCPToolApproval(ctx, perm); err != nil {
+		slog.Error("Failed to settle tool approval", "role_id", perm.RoleID, "tool_id", perm.ToolID, "error", err)
+	}
+}
+
+// decide records the decision on a pending request, so two admins can't
+// decide the same request, and returns the updated request
+func (s *Service) decide(ctx context.Context, id, status string, visibility domain.MCPToolVisibility, reason, reviewerID, reviewerEmail string) (*domain.MCPToolApproval, error) {
+	a, err := s.store.GetMCPToolApproval(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("get tool approval: %w", err)
+	}
+	if a == nil {
+		return nil, ErrNotFound
+	}
+	ok, err := s.store.DecideMCPToolApproval(ctx, id, status, visibility, reviewerID, reviewerEmail, reason)
+	if err != nil {
+		return nil, fmt.Errorf("decide tool approval: %w", err)
+	}
+	if !ok {
+		return nil, ErrAlreadyDecided
+	}
+	now := time.Now()
+	a.Status, a.Visibility, a.DecisionReason = status, visibility, reason
+	a.DecidedBy, a.DecidedByEmail, a.DecidedAt = reviewerID, reviewerEmail, &now
+	return a, nil
+}
+
+// notifyAdmins emails the configured addresses, or every admin, about a new request
+func (s *Service) notifyAdmins(ctx context.Context, a *domain.MCPToolApproval) {
+	if s.sender == nil {
+		return
+	}
+	to := s.cfg.NotifyEmails
+	if len(to) == 0 {
+		admins, err := s.store.ListAdminEmails(ctx)
+		if err != nil {
+			slog.Error("Failed to list admins for tool approval notification", "error", err)
+			return
+		}
+		to = admins
+	}
+	if len(to) == 0 {
+		return
+	}
+	msg := &email.Message{
+		To:      to,
+		Subject: fmt.Sprintf("Role %s is asking to use MCP tool %s", a.RoleName, a.ToolName),
+		Text: fmt.Sprintf("Role %s called %s on MCP server %s, which it has no permission for. Approve or deny it under MCP Servers > Approvals in the admin portal.",
+			a.RoleName, a.ToolName, a.ServerName),
+	}
+	if err := s.sender.Send(ctx, msg); err != nil {
+		slog.Error("Failed to send tool approval email", "subject", msg.Subject, "error", err)
+	}
+}
+
+// approvalEvent is the payload posted to the notification webhook
+type approvalEvent struct {
+	Event    string                  `json:"event"`
+	Approval *domain.MCPToolApproval `json:"approval"`
+}
+
+// postWebhook posts an event to the notification webhook. Failures are
+// logged; the event is not retried.
+func (s *Service) postWebhook(ctx context.Context, event string, a *domain.MCPToolApproval) {
+	if s.cfg.NotifyWebhook == "" {
+		return
+	}
+	body, err := json.Marshal(approvalEvent{Event: event, Approval: a})
+	if err != nil {
+		return
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.NotifyWebhook, bytes.NewReader(body))
+	if err != nil {
+		slog.Error("Failed to build tool approval webhook", "error", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		slog.Error("Failed to send tool approval webhook", "event", event, "error", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		slog.Error("Tool approval webhook failed", "event", event, "status", resp.Status)
+	}
+}
@@ -0,0 +1,124 @@
+package toolapproval
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+	"modelgate/internal/email"
+)
+
+type fakeStore struct {
+	approvals   map[string]*domain.MCPToolApproval // Role ID + tool ID -> approval
+	permissions []*domain.MCPToolPermission
+}
+
+func (f *fakeStore) RequestMCPToolApproval(ctx context.Context, roleID, serverID, toolID, apiKeyID string) (*domain.MCPToolApproval, error) {
+	key := roleID + "/" + toolID
+	a, ok := f.approvals[key]
+	if !ok || a.Status == domain.MCPApprovalApproved {
+		a = &domain.MCPToolApproval{ID: key, RoleID: roleID, ServerID: serverID, ToolID: toolID, RoleName: "support",
+			ToolName: "create_issue", ServerName: "github", Status: domain.MCPApprovalPending, RequestedBy: apiKeyID}
+		f.approvals[key] = a
+	}
+	a.RequestCount++
+	copied := *a
+	return &copied, nil
+}
+
+func (f *fakeStore) GetMCPToolApproval(ctx context.Context, id string) (*domain.MCPToolApproval, error) {
+	if a, ok := f.approvals[id]; ok {
+		copied := *a
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (f *fakeStore) ListMCPToolApprovals(ctx context.Context, status string) ([]*domain.MCPToolApproval, error) {
+	return nil, nil
+}
+
+func (f *fakeStore) DecideMCPToolApproval(ctx context.Context, id, status string, visibility domain.MCPToolVisibility, decidedBy, decidedByEmail, reason string) (bool, error) {
+	a, ok := f.approvals[id]
+	if !ok || a.Status != domain.MCPApprovalPending {
+		return false, nil
+	}
+	a.Status, a.Visibility, a.DecisionReason = status, visibility, reason
+	return true, nil
+}
+
+func (f *fakeStore) SettleMCPToolApproval(ctx context.Context, perm *domain.MCPToolPermission) error {
+	return nil
+}
+
+func (f *fakeStore) SetMCPToolPermission(ctx context.Context, perm *domain.MCPToolPermission) error {
+	f.permissions = append(f.permissions, perm)
+	return nil
+}
+
+func (f *fakeStore) ListAdminEmails(ctx context.Context) ([]string, error) {
+	return []string{"root@example.com"}, nil
+}
+
+type fakeSender struct{ sent []*email.Message }
+
+func (s *fakeSender) Send(ctx context.Context, msg *email.Message) error {
+	s.sent = append(s.sent, msg)
+	return nil
+}
+
+func TestToolApprovalWorkflow(t *testing.T) {
+	ctx := context.Background()
+	store := &fakeStore{approvals: map[string]*domain.MCPToolApproval{}}
+	sender := &fakeSender{}
+	svc := NewService(store, config.ToolApprovalConfig{Enabled: true}, sender)
+	server := &domain.MCPServer{ID: "srv-1", Name: "github"}
+	tool := &domain.MCPTool{ID: "tool-1", Name: "create_issue"}
+
+	// The first call opens a request and notifies admins; repeats only count
+	a, err := svc.Request(ctx, "role-1", "key-1", server, tool)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if a.Status != domain.MCPApprovalPending || len(sender.sent) != 1 || sender.sent[0].To[0] != "root@example.com" {
+		t.Fatalf("expected a pending request and one notification, got %+v, %d emails", a, len(sender.sent))
+	}
+	if a, _ = svc.Request(ctx, "role-1", "key-2", server, tool); a.RequestCount != 2 || len(sender.sent) != 1 {
+		t.Fatalf("expected the repeat to be counted without notifying, got %+v, %d emails", a, len(sender.sent))
+	}
+
+	if _, err := svc.Approve(ctx, a.ID, domain.MCPVisibilityDeny, "", "u1", "root@example.com"); !errors.Is(err, ErrInvalidGrant) {
+		t.Fatalf("expected DENY to be refused as a grant, got %v", err)
+	}
+	if _, err := svc.Deny(ctx, a.ID, "  ", "u1", "root@example.com"); !errors.Is(err, ErrReasonRequired) {
+		t.Fatalf("expected a reason to be required, got %v", err)
+	}
+
+	// Approving grants the role the tool
+	approved, err := svc.Approve(ctx, a.ID, "", "read-only use", "u1", "root@example.com")
+	if err != nil {
+		t.Fatalf("Approve failed: %v", err)
+	}
+	if approved.Status != domain.MCPApprovalApproved || len(store.permissions) != 1 ||
+		store.permissions[0].Visibility != domain.MCPVisibilityAllow || store.permissions[0].DecidedBy != "u1" {
+		t.Fatalf("expected ALLOW to be granted, got %+v, %+v", approved, store.permissions)
+	}
+	if _, err := svc.Deny(ctx, a.ID, "changed my mind", "u2", "ops@example.com"); !errors.Is(err, ErrAlreadyDecided) {
+		t.Fatalf("expected a decided request to stay decided, got %v", err)
+	}
+
+	// A denied request keeps its reason for later calls
+	other := &domain.MCPTool{ID: "tool-2", Name: "delete_repo"}
+	a, _ = svc.Request(ctx, "role-1", "key-1", server, other)
+	if _, err := svc.Deny(ctx, a.ID, "destructive", "u1", "root@example.com"); err != nil {
+		t.Fatalf("Deny failed: %v", err)
+	}
+	if a, _ = svc.Request(ctx, "role-1", "key-1", server, other); a.Status != domain.MCPApprovalDenied || a.DecisionReason != "destructive" {
+		t.Fatalf("expected the denial to stick, got %+v", a)
+	}
+	if _, err := svc.Approve(ctx, "missing", "", "", "u1", "root@example.com"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
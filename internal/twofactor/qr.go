@@ -0,0 +1,443 @@
+package twofactor
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// A minimal QR code encoder (ISO/IEC 18004) for provisioning URIs: byte mode,
+// error correction level M, versions 1 to 10, which hold up to 213 bytes.
+
+// ErrTooLong is returned for text that doesn't fit a version 10 QR code
+var ErrTooLong = errors.New("text is too long for a QR code")
+
+// qrVersion is the block structure of a version at error correction level M
+type qrVersion struct {
+	ecPerBlock int
+	blocks     [2][2]int // {count, data codewords} of each of the two block groups
+	alignment  []int     // Alignment pattern centers
+}
+
+var qrVersions = [...]qrVersion{
+	1:  {10, [2][2]int{{1, 16}}, nil},
+	2:  {16, [2][2]int{{1, 28}}, []int{6, 18}},
+	3:  {26, [2][2]int{{1, 44}}, []int{6, 22}},
+	4:  {18, [2][2]int{{2, 32}}, []int{6, 26}},
+	5:  {24, [2][2]int{{2, 43}}, []int{6, 30}},
+	6:  {16, [2][2]int{{4, 27}}, []int{6, 34}},
+	7:  {18, [2][2]int{{4, 31}}, []int{6, 22, 38}},
+	8:  {22, [2][2]int{{2, 38}, {2, 39}}, []int{6, 24, 42}},
+	9:  {22, [2][2]int{{3, 36}, {2, 37}}, []int{6, 26, 46}},
+	10: {26, [2][2]int{{4, 43}, {1, 44}}, []int{6, 28, 50}},
+}
+
+func (v qrVersion) dataCodewords() int {
+	return v.blocks[0][0]*v.blocks[0][1] + v.blocks[1][0]*v.blocks[1][1]
+}
+
+// qrCode is a QR symbol being drawn; modules are true when dark
+type qrCode struct {
+	size     int
+	modules  [][]bool
+	function [][]bool // Modules of function patterns, which masks skip
+}
+
+// QRCodePNG renders text as a QR code PNG, scale pixels per module, with the
+// four module quiet zone scanners expect
+func QRCodePNG(text string, scale int) ([]byte, error) {
+	q, err := encodeQR([]byte(text))
+	if err != nil {
+		return nil, err
+	}
+	const quiet = 4
+	side := (q.size + 2*quiet) * scale
+	img := image.NewGray(image.Rect(0, 0, side, side))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			if !q.modules[y][x] {
+				continue
+			}
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					img.SetGray((x+quiet)*scale+dx, (y+quiet)*scale+dy, color.Gray{})
+				}
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeQR encodes data in the smallest version that holds it, with the
+// mask that scores the lowest penalty
+func encodeQR(data []byte) (*qrCode, error) {
+	version := 0
+	for v := 1; v < len(qrVersions); v++ {
+		countBits := 8
+		if v >= 10 {
+			countBits = 16
+		}
+		if 4+countBits+8*len(data) <= 8*qrVersions[v].dataCodewords() {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, ErrTooLong
+	}
+	codewords := qrCodewords(data, version)
+
+	size := 17 + 4*version
+	q := &qrCode{size: size, modules: make([][]bool, size), function: make([][]bool, size)}
+	for i := range q.modules {
+		q.modules[i] = make([]bool, size)
+		q.function[i] = make([]bool, size)
+	}
+	q.drawFunctionPatterns(version)
+	q.drawCodewords(codewords)
+
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		q.applyMask(mask)
+		q.drawFormat(mask)
+		if p := q.penalty(); bestPenalty < 0 || p < bestPenalty {
+			best, bestPenalty = mask, p
+		}
+		q.applyMask(mask) // Masks are their own inverse
+	}
+	q.applyMask(best)
+	q.drawFormat(best)
+	return q, nil
+}
+
+// qrCodewords returns the data and error correction codewords of a version,
+// interleaved in the order they are placed
+func qrCodewords(data []byte, version int) []byte {
+	v := qrVersions[version]
+	capacity := v.dataCodewords()
+
+	var bits bitBuffer
+	bits.append(0b0100, 4) // Byte mode
+	if version >= 10 {
+		bits.append(len(data), 16)
+	} else {
+		bits.append(len(data), 8)
+	}
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+	bits.append(0, min(4, 8*capacity-len(bits))) // Terminator
+	bits.append(0, (8-len(bits)%8)%8)
+	for pad := 0xEC; len(bits) < 8*capacity; pad ^= 0xEC ^ 0x11 {
+		bits.append(pad, 8)
+	}
+	dataCodewords := bits.bytes()
+
+	var blocks, ecBlocks [][]byte
+	for _, group := range v.blocks {
+		for i := 0; i < group[0]; i++ {
+			block := dataCodewords[:group[1]]
+			dataCodewords = dataCodewords[group[1]:]
+			blocks = append(blocks, block)
+			ecBlocks = append(ecBlocks, reedSolomon(block, v.ecPerBlock))
+		}
+	}
+	var out []byte
+	for i := 0; i < v.blocks[0][1]+1; i++ {
+		for _, block := range blocks {
+			if i < len(block) {
+				out = append(out, block[i])
+			}
+		}
+	}
+	for i := 0; i < v.ecPerBlock; i++ {
+		for _, ec := range ecBlocks {
+			out = append(out, ec[i])
+		}
+	}
+	return out
+}
+
+type bitBuffer []bool
+
+func (b *bitBuffer) append(value, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*b = append(*b, value>>i&1 == 1)
+	}
+}
+
+func (b bitBuffer) bytes() []byte {
+	out := make([]byte, len(b)/8)
+	for i, bit := range b {
+		if bit {
+			out[i/8] |= 0x80 >> (i % 8)
+		}
+	}
+	return out
+}
+
+// gfMul multiplies in GF(256) modulo x^8 + x^4 + x^3 + x^2 + 1
+func gfMul(x, y byte) byte {
+	var z byte
+	for i := 7; i >= 0; i-- {
+		z = z<<1 ^ (z>>7)*0x1D
+		z ^= (y >> i & 1) * x
+	}
+	return z
+}
+
+// reedSolomon returns the n error correction codewords of a block
+func reedSolomon(data []byte, n int) []byte {
+	// The generator polynomial, the product of (x - 2^i) for i < n, with its
+	// leading 1 left out
+	gen := make([]byte, n)
+	gen[n-1] = 1
+	root := byte(1)
+	for i := 0; i < n; i++ {
+		for j := range gen {
+			gen[j] = gfMul(gen[j], root)
+			if j+1 < n {
+				gen[j] ^= gen[j+1]
+			}
+		}
+		root = gfMul(root, 2)
+	}
+
+	rem := make([]byte, n)
+	for _, b := range data {
+		factor := b ^ rem[0]
+		copy(rem, rem[1:])
+		rem[n-1] = 0
+		for j := range rem {
+			rem[j] ^= gfMul(gen[j], factor)
+		}
+	}
+	return rem
+}
+
+func (q *qrCode) set(x, y int, dark bool) {
+	q.modules[y][x] = dark
+	q.function[y][x] = true
+}
+
+func (q *qrCode) drawFunctionPatterns(version int) {
+	for i := 0; i < q.size; i++ {
+		q.set(6, i, i%2 == 0)
+		q.set(i, 6, i%2 == 0)
+	}
+
+	// Finder patterns with their separators
+	for _, c := range [][2]int{{3, 3}, {q.size - 4, 3}, {3, q.size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := c[0]+dx, c[1]+dy
+				if x >= 0 && x < q.size && y >= 0 && y < q.size {
+					d := max(abs(dx), abs(dy))
+					q.set(x, y, d != 2 && d != 4)
+				}
+			}
+		}
+	}
+
+	align := qrVersions[version].alignment
+	last := len(align) - 1
+	for i, cx := range align {
+		for j, cy := range align {
+			if i == 0 && j == 0 || i == 0 && j == last || i == last && j == 0 {
+				continue // Overlaps a finder pattern
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					q.set(cx+dx, cy+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+
+	q.drawFormat(0) // Reserves the format areas
+	if version >= 7 {
+		bits := version<<12 | bchRemainder(version, 0x1F25, 12)
+		for i := 0; i < 18; i++ {
+			a, b := q.size-11+i%3, i/3
+			q.set(a, b, bits>>i&1 == 1)
+			q.set(b, a, bits>>i&1 == 1)
+		}
+	}
+}
+
+// bchRemainder returns the check bits of a BCH code
+func bchRemainder(value, generator, bits int) int {
+	rem := value
+	for i := 0; i < bits; i++ {
+		rem = rem<<1 ^ (rem>>(bits-1))*generator
+	}
+	return rem
+}
+
+// formatBits returns the format information of level M with a mask
+func formatBits(mask int) int {
+	data := 0b00<<3 | mask // Level M
+	return (data<<10 | bchRemainder(data, 0x537, 10)) ^ 0x5412
+}
+
+func (q *qrCode) drawFormat(mask int) {
+	bits := formatBits(mask)
+	bit := func(i int) bool { return bits>>i&1 == 1 }
+	for i := 0; i <= 5; i++ {
+		q.set(8, i, bit(i))
+	}
+	q.set(8, 7, bit(6))
+	q.set(8, 8, bit(7))
+	q.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		q.set(14-i, 8, bit(i))
+	}
+	for i := 0; i < 8; i++ {
+		q.set(q.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		q.set(8, q.size-15+i, bit(i))
+	}
+	q.set(8, q.size-8, true) // The dark module
+}
+
+// drawCodewords places the codewords in the zigzag of column pairs, from the
+// bottom right, around the function patterns
+func (q *qrCode) drawCodewords(codewords []byte) {
+	i := 0
+	for right := q.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5 // Skip the vertical timing pattern
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < q.size; vert++ {
+			y := vert
+			if upward {
+				y = q.size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if q.function[y][x] || i >= len(codewords)*8 {
+					continue
+				}
+				q.modules[y][x] = codewords[i/8]>>(7-i%8)&1 == 1
+				i++
+			}
+		}
+	}
+}
+
+func (q *qrCode) applyMask(mask int) {
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			if q.function[y][x] {
+				continue
+			}
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			q.modules[y][x] = q.modules[y][x] != invert
+		}
+	}
+}
+
+// penalty scores how hard the symbol is to scan; the mask with the lowest
+// score is used
+func (q *qrCode) penalty() int {
+	n := q.size
+	at := func(x, y int, vertical bool) bool {
+		if vertical {
+			return q.modules[x][y]
+		}
+		return q.modules[y][x]
+	}
+	finderLike := [2][11]bool{
+		{true, false, true, true, true, false, true, false, false, false, false},
+		{false, false, false, false, true, false, true, true, true, false, true},
+	}
+
+	score := 0
+	for _, vertical := range []bool{false, true} {
+		for y := 0; y < n; y++ {
+			// Runs of five or more modules of one color
+			run := 1
+			for x := 1; x <= n; x++ {
+				if x < n && at(x, y, vertical) == at(x-1, y, vertical) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					score += 3 + run - 5
+				}
+				run = 1
+			}
+			// Patterns that look like finders
+			for x := 0; x+11 <= n; x++ {
+				for _, pattern := range finderLike {
+					match := true
+					for k, dark := range pattern {
+						if at(x+k, y, vertical) != dark {
+							match = false
+							break
+						}
+					}
+					if match {
+						score += 40
+					}
+				}
+			}
+		}
+	}
+
+	dark := 0
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			if q.modules[y][x] {
+				dark++
+			}
+			// 2x2 blocks of one color
+			if x+1 < n && y+1 < n {
+				c := q.modules[y][x]
+				if q.modules[y][x+1] == c && q.modules[y+1][x] == c && q.modules[y+1][x+1] == c {
+					score += 3
+				}
+			}
+		}
+	}
+	// Imbalance of dark and light, in steps of 5% away from half
+	total := n * n
+	score += abs(dark*20-total*10) / total * 10
+	return score
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
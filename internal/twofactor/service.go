@@ -0,0 +1,248 @@
+package twofactor
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+	"modelgate/internal/passwords"
+)
+
+// qrScale is the pixels per module of provisioning QR codes
+const qrScale = 6
+
+var (
+	ErrCodeRequired       = errors.New("two-factor code required")
+	ErrEnrollmentRequired = errors.New("two-factor authentication must be set up before signing in")
+	ErrInvalidCode        = errors.New("invalid two-factor code")
+	ErrAlreadyEnabled     = errors.New("two-factor authentication is already set up")
+	ErrNotEnrolling       = errors.New("two-factor enrollment was not started")
+	ErrNotEnabled         = errors.New("two-factor authentication is not set up")
+	ErrRequired           = errors.New("two-factor authentication is required for your role and can't be turned off")
+)
+
+// Store is the persistence used by the service (implemented by postgres.TenantStore)
+type Store interface {
+	GetTwoFactorSettings(ctx context.Context) (*domain.TwoFactorSettings, error)
+	SaveTwoFactorSettings(ctx context.Context, settings *domain.TwoFactorSettings) error
+	GetUserTwoFactor(ctx context.Context, userID string) (*domain.UserTwoFactor, error)
+	SaveUserTwoFactorSecret(ctx context.Context, userID, secret string) (bool, error)
+	EnableUserTwoFactor(ctx context.Context, userID string, step int64, backupCodeHashes []string) (bool, error)
+	ReplaceBackupCodes(ctx context.Context, userID string, hashes []string) error
+	UseTwoFactorStep(ctx context.Context, userID string, step int64) (bool, error)
+	UseBackupCode(ctx context.Context, userID, hash string) (bool, error)
+	DeleteUserTwoFactor(ctx context.Context, userID string) (bool, error)
+	RecordFailedTwoFactor(ctx context.Context, userID string) (time.Time, error)
+	ResetTwoFactorFailures(ctx context.Context, userID string) error
+}
+
+// Enrollment is what a user needs to add their secret to an authenticator app
+type Enrollment struct {
+	Secret          string
+	ProvisioningURI string
+	QRCodeDataURL   string // PNG of the provisioning URI
+}
+
+// Service enrolls users and checks their codes
+type Service struct {
+	cfg   config.TwoFactorConfig
+	store Store
+	now   func() time.Time
+}
+
+// NewService creates a two-factor service
+func NewService(cfg config.TwoFactorConfig, store Store) *Service {
+	return &Service{cfg: cfg, store: store, now: time.Now}
+}
+
+// Settings returns the tenant's two-factor requirements
+func (s *Service) Settings(ctx context.Context) (domain.TwoFactorSettings, error) {
+	settings, err := s.store.GetTwoFactorSettings(ctx)
+	if err != nil || settings == nil {
+		return domain.TwoFactorSettings{}, err
+	}
+	return *settings, nil
+}
+
+// UpdateSettings replaces the tenant's two-factor requirements
+func (s *Service) UpdateSettings(ctx context.Context, settings domain.TwoFactorSettings, updatedBy string) (*domain.TwoFactorSettings, error) {
+	settings.UpdatedBy = updatedBy
+	settings.UpdatedAt = s.now()
+	if err := s.store.SaveTwoFactorSettings(ctx, &settings); err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// BeginEnrollment gives a user a new secret to add to their authenticator
+// app. It takes effect once ConfirmEnrollment accepts a code of it.
+func (s *Service) BeginEnrollment(ctx context.Context, userID, account string) (*Enrollment, error) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	saved, err := s.store.SaveUserTwoFactorSecret(ctx, userID, secret)
+	if err != nil {
+		return nil, err
+	}
+	if !saved {
+		return nil, ErrAlreadyEnabled
+	}
+
+	uri := ProvisioningURI(s.cfg.Issuer, account, secret)
+	png, err := QRCodePNG(uri, qrScale)
+	if err != nil {
+		return nil, err
+	}
+	return &Enrollment{
+		Secret:          secret,
+		ProvisioningURI: uri,
+		QRCodeDataURL:   "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+	}, nil
+}
+
+// ConfirmEnrollment turns two-factor authentication on with a code from the
+// user's app, and returns their backup codes
+func (s *Service) ConfirmEnrollment(ctx context.Context, userID, code string) ([]string, error) {
+	enrollment, err := s.store.GetUserTwoFactor(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if enrollment == nil {
+		return nil, ErrNotEnrolling
+	}
+	if enrollment.Enabled {
+		return nil, ErrAlreadyEnabled
+	}
+	step, ok := Validate(enrollment.Secret, code, s.now(), s.cfg.Skew)
+	if !ok {
+		return nil, ErrInvalidCode
+	}
+
+	codes, hashes, err := GenerateBackupCodes(s.cfg.BackupCodes)
+	if err != nil {
+		return nil, err
+	}
+	enabled, err := s.store.EnableUserTwoFactor(ctx, userID, step, hashes)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		return nil, ErrNotEnrolling
+	}
+	return codes, nil
+}
+
+// Verify is the two-factor step of a login: an enrolled user must give a code
+// from their app or an unused backup code. A user who isn't enrolled passes,
+// unless their role must be. Wrong codes count towards locking the account,
+// and get a *passwords.LockedError once it is.
+func (s *Service) Verify(ctx context.Context, userID, role, code string) error {
+	enrollment, err := s.store.GetUserTwoFactor(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if enrollment == nil || !enrollment.Enabled {
+		settings, err := s.Settings(ctx)
+		if err != nil {
+			return err
+		}
+		if settings.Requires(role) {
+			return ErrEnrollmentRequired
+		}
+		return nil
+	}
+	return s.check(ctx, enrollment, code)
+}
+
+// check accepts a TOTP code of a time step not used before, or an unused
+// backup code
+func (s *Service) check(ctx context.Context, enrollment *domain.UserTwoFactor, code string) error {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return ErrCodeRequired
+	}
+
+	var ok bool
+	var err error
+	if step, valid := Validate(enrollment.Secret, code, s.now(), s.cfg.Skew); valid {
+		ok, err = s.store.UseTwoFactorStep(ctx, enrollment.UserID, step)
+	} else {
+		ok, err = s.store.UseBackupCode(ctx, enrollment.UserID, HashBackupCode(code))
+	}
+	if err != nil {
+		return err
+	}
+	if !ok {
+		until, err := s.store.RecordFailedTwoFactor(ctx, enrollment.UserID)
+		if err != nil {
+			return err
+		}
+		if !until.IsZero() {
+			return &passwords.LockedError{Until: until}
+		}
+		return ErrInvalidCode
+	}
+	return s.store.ResetTwoFactorFailures(ctx, enrollment.UserID)
+}
+
+// Disable turns a user's two-factor authentication off, with a code, unless
+// their role requires it
+func (s *Service) Disable(ctx context.Context, userID, role, code string) error {
+	enrollment, err := s.enabled(ctx, userID)
+	if err != nil {
+		return err
+	}
+	settings, err := s.Settings(ctx)
+	if err != nil {
+		return err
+	}
+	if settings.Requires(role) {
+		return ErrRequired
+	}
+	if err := s.check(ctx, enrollment, code); err != nil {
+		return err
+	}
+	_, err = s.store.DeleteUserTwoFactor(ctx, userID)
+	return err
+}
+
+// RegenerateBackupCodes replaces a user's backup codes, with a code
+func (s *Service) RegenerateBackupCodes(ctx context.Context, userID, code string) ([]string, error) {
+	enrollment, err := s.enabled(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.check(ctx, enrollment, code); err != nil {
+		return nil, err
+	}
+	codes, hashes, err := GenerateBackupCodes(s.cfg.BackupCodes)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.ReplaceBackupCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (s *Service) enabled(ctx context.Context, userID string) (*domain.UserTwoFactor, error) {
+	enrollment, err := s.store.GetUserTwoFactor(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if enrollment == nil || !enrollment.Enabled {
+		return nil, ErrNotEnabled
+	}
+	return enrollment, nil
+}
+
+// Reset removes a user's enrollment, for an admin helping a user who lost
+// their app and backup codes. It returns false if the user wasn't enrolled.
+func (s *Service) Reset(ctx context.Context, userID string) (bool, error) {
+	return s.store.DeleteUserTwoFactor(ctx, userID)
+}
@@ -0,0 +1,293 @@
+package twofactor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+	"modelgate/internal/passwords"
+)
+
+// The RFC 6238 SHA-1 secret, "12345678901234567890"
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPVectors(t *testing.T) {
+	// RFC 6238 appendix B, truncated to 6 digits
+	for unix, want := range map[int64]string{59: "287082", 1111111109: "081804", 1234567890: "005924", 2000000000: "279037"} {
+		at := time.Unix(unix, 0)
+		if got, _ := code(rfcSecret, step(at)); got != want {
+			t.Errorf("code at %d: got %s, want %s", unix, got, want)
+		}
+		if s, ok := Validate(rfcSecret, want, at, 0); !ok || s != step(at) {
+			t.Errorf("expected %s to validate at %d", want, unix)
+		}
+	}
+
+	at := time.Unix(1111111109, 0)
+	if _, ok := Validate(rfcSecret, "081804", at.Add(30*time.Second), 1); !ok {
+		t.Error("expected the previous step's code to validate with a skew of 1")
+	}
+	if _, ok := Validate(rfcSecret, "081804", at.Add(30*time.Second), 0); ok {
+		t.Error("expected the previous step's code to fail without skew")
+	}
+	if _, ok := Validate(rfcSecret, "08180", at, 1); ok {
+		t.Error("expected a short code to fail")
+	}
+}
+
+func TestProvisioningURI(t *testing.T) {
+	uri := ProvisioningURI("ModelGate", "jane@example.com", rfcSecret)
+	if !strings.HasPrefix(uri, "otpauth://totp/ModelGate:jane@example.com?") || !strings.Contains(uri, "secret="+rfcSecret) {
+		t.Fatalf("unexpected URI %s", uri)
+	}
+}
+
+func TestQRCode(t *testing.T) {
+	// The "HELLO WORLD" 1-M example: its data codewords and their error correction
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	if got := reedSolomon(data, 10); !bytes.Equal(got, want) {
+		t.Fatalf("error correction: got %v, want %v", got, want)
+	}
+	if got := formatBits(0); got != 0b101010000010010 {
+		t.Fatalf("format bits of M, mask 0: got %015b", got)
+	}
+	if got := 7<<12 | bchRemainder(7, 0x1F25, 12); got != 0b000111110010010100 {
+		t.Fatalf("version 7 bits: got %018b", got)
+	}
+
+	uri := ProvisioningURI("ModelGate", "someone.with.a.long.name@example.com", rfcSecret)
+	q, err := encodeQR([]byte(uri))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.size != 17+4*8 {
+		t.Fatalf("expected a %d byte URI to need version 8, got size %d", len(uri), q.size)
+	}
+	// Reading the symbol back gives the codewords that were placed
+	format := 0
+	for i, pos := range [15][2]int{{8, 0}, {8, 1}, {8, 2}, {8, 3}, {8, 4}, {8, 5}, {8, 7}, {8, 8}, {7, 8}, {5, 8}, {4, 8}, {3, 8}, {2, 8}, {1, 8}, {0, 8}} {
+		if q.modules[pos[1]][pos[0]] {
+			format |= 1 << i
+		}
+	}
+	mask := -1
+	for m := 0; m < 8; m++ {
+		if formatBits(m) == format {
+			mask = m
+		}
+	}
+	if mask < 0 {
+		t.Fatal("no mask matches the format information")
+	}
+	q.applyMask(mask)
+	placed := qrCodewords([]byte(uri), 8)
+	read := make([]byte, len(placed))
+	i := 0
+	for right := q.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < q.size; vert++ {
+			y := vert
+			if (right+1)&2 == 0 {
+				y = q.size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				if x := right - j; !q.function[y][x] && i < len(read)*8 {
+					if q.modules[y][x] {
+						read[i/8] |= 0x80 >> (i % 8)
+					}
+					i++
+				}
+			}
+		}
+	}
+	q.applyMask(mask)
+	if !bytes.Equal(read, placed) || i != len(placed)*8 {
+		t.Fatal("the codewords read back differ from those placed")
+	}
+	if first := placed[0]; first>>4 != 0b0100 {
+		t.Fatalf("expected byte mode, got %08b", first)
+	}
+	if q.modules[q.size-8][8] != true || q.modules[0][0] != true || q.modules[7][7] != false {
+		t.Fatal("expected the finder patterns and dark module in place")
+	}
+
+	img, err := QRCodePNG(uri, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := png.Decode(bytes.NewReader(img))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := decoded.Bounds().Dx(); w != (q.size+8)*2 {
+		t.Fatalf("unexpected image width %d", w)
+	}
+	if _, err := encodeQR(make([]byte, 214)); err != ErrTooLong {
+		t.Fatalf("expected text past version 10 to be refused, got %v", err)
+	}
+}
+
+type fakeStore struct {
+	settings    *domain.TwoFactorSettings
+	enrollment  *domain.UserTwoFactor
+	backupCodes map[string]bool // Hash to used
+	failures    int
+}
+
+func (f *fakeStore) GetTwoFactorSettings(ctx context.Context) (*domain.TwoFactorSettings, error) {
+	return f.settings, nil
+}
+
+func (f *fakeStore) SaveTwoFactorSettings(ctx context.Context, settings *domain.TwoFactorSettings) error {
+	f.settings = settings
+	return nil
+}
+
+func (f *fakeStore) GetUserTwoFactor(ctx context.Context, userID string) (*domain.UserTwoFactor, error) {
+	if f.enrollment == nil {
+		return nil, nil
+	}
+	e := *f.enrollment
+	return &e, nil
+}
+
+func (f *fakeStore) SaveUserTwoFactorSecret(ctx context.Context, userID, secret string) (bool, error) {
+	if f.enrollment != nil && f.enrollment.Enabled {
+		return false, nil
+	}
+	f.enrollment = &domain.UserTwoFactor{UserID: userID, Secret: secret}
+	return true, nil
+}
+
+func (f *fakeStore) EnableUserTwoFactor(ctx context.Context, userID string, step int64, hashes []string) (bool, error) {
+	f.enrollment.Enabled, f.enrollment.LastUsedStep = true, step
+	return true, f.ReplaceBackupCodes(ctx, userID, hashes)
+}
+
+func (f *fakeStore) ReplaceBackupCodes(ctx context.Context, userID string, hashes []string) error {
+	f.backupCodes = map[string]bool{}
+	for _, h := range hashes {
+		f.backupCodes[h] = false
+	}
+	return nil
+}
+
+func (f *fakeStore) UseTwoFactorStep(ctx context.Context, userID string, step int64) (bool, error) {
+	if step <= f.enrollment.LastUsedStep {
+		return false, nil
+	}
+	f.enrollment.LastUsedStep = step
+	return true, nil
+}
+
+func (f *fakeStore) UseBackupCode(ctx context.Context, userID, hash string) (bool, error) {
+	if used, ok := f.backupCodes[hash]; !ok || used {
+		return false, nil
+	}
+	f.backupCodes[hash] = true
+	return true, nil
+}
+
+func (f *fakeStore) DeleteUserTwoFactor(ctx context.Context, userID string) (bool, error) {
+	had := f.enrollment != nil
+	f.enrollment = nil
+	return had, nil
+}
+
+func (f *fakeStore) RecordFailedTwoFactor(ctx context.Context, userID string) (time.Time, error) {
+	f.failures++
+	if f.failures >= 3 {
+		f.failures = 0
+		return time.Now().Add(time.Minute), nil
+	}
+	return time.Time{}, nil
+}
+
+func (f *fakeStore) ResetTwoFactorFailures(ctx context.Context, userID string) error {
+	f.failures = 0
+	return nil
+}
+
+func TestEnrollAndVerify(t *testing.T) {
+	ctx := context.Background()
+	store := &fakeStore{}
+	s := NewService(config.Default().TwoFactor, store)
+	now := time.Unix(1111111109, 0)
+	s.now = func() time.Time { return now }
+
+	// Not enrolled: passes unless the role requires it
+	if err := s.Verify(ctx, "u1", "admin", ""); err != nil {
+		t.Fatalf("expected an unenrolled user to pass, got %v", err)
+	}
+	s.UpdateSettings(ctx, domain.TwoFactorSettings{RequireForAdmins: true}, "admin@example.com")
+	if err := s.Verify(ctx, "u1", "admin", ""); err != ErrEnrollmentRequired {
+		t.Fatalf("expected enrollment to be required of admins, got %v", err)
+	}
+	if err := s.Verify(ctx, "u2", "member", ""); err != nil {
+		t.Fatalf("expected members to pass, got %v", err)
+	}
+
+	enrollment, err := s.BeginEnrollment(ctx, "u1", "admin@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(enrollment.QRCodeDataURL, "data:image/png;base64,") {
+		t.Fatalf("unexpected QR code %.40s", enrollment.QRCodeDataURL)
+	}
+	if _, err := s.ConfirmEnrollment(ctx, "u1", "000000"); err != ErrInvalidCode {
+		t.Fatalf("expected a wrong code to be refused, got %v", err)
+	}
+	first, _ := code(enrollment.Secret, step(now))
+	backup, err := s.ConfirmEnrollment(ctx, "u1", first)
+	if err != nil || len(backup) != 10 {
+		t.Fatalf("expected 10 backup codes, got %v, %v", backup, err)
+	}
+	if _, err := s.BeginEnrollment(ctx, "u1", "admin@example.com"); err != ErrAlreadyEnabled {
+		t.Fatalf("expected a second enrollment to be refused, got %v", err)
+	}
+
+	// The confirming code can't be replayed; the next one works once
+	if err := s.Verify(ctx, "u1", "admin", ""); err != ErrCodeRequired {
+		t.Fatalf("expected a code to be required, got %v", err)
+	}
+	if err := s.Verify(ctx, "u1", "admin", first); err != ErrInvalidCode {
+		t.Fatalf("expected a used code to be refused, got %v", err)
+	}
+	now = now.Add(30 * time.Second)
+	next, _ := code(enrollment.Secret, step(now))
+	if err := s.Verify(ctx, "u1", "admin", next); err != nil {
+		t.Fatalf("expected the next code to pass, got %v", err)
+	}
+	if store.failures != 0 {
+		t.Fatalf("expected an accepted code to reset failures, got %d", store.failures)
+	}
+
+	// Backup codes work once, in any case and with spaces
+	if err := s.Verify(ctx, "u1", "admin", " "+strings.ToUpper(backup[0])+" "); err != nil {
+		t.Fatalf("expected a backup code to pass, got %v", err)
+	}
+	if err := s.Verify(ctx, "u1", "admin", backup[0]); err != ErrInvalidCode {
+		t.Fatalf("expected a used backup code to be refused, got %v", err)
+	}
+	s.Verify(ctx, "u1", "admin", "nope")
+	var locked *passwords.LockedError
+	if err := s.Verify(ctx, "u1", "admin", "nope"); !errors.As(err, &locked) {
+		t.Fatalf("expected the account to lock after wrong codes, got %v", err)
+	}
+
+	if err := s.Disable(ctx, "u1", "admin", backup[1]); err != ErrRequired {
+		t.Fatalf("expected admins to be kept enrolled, got %v", err)
+	}
+	if err := s.Disable(ctx, "u1", "member", backup[1]); err != nil || store.enrollment != nil {
+		t.Fatalf("expected a member to turn two-factor off, got %v", err)
+	}
+}
@@ -0,0 +1,191 @@
+package usageexport
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"modelgate/internal/domain"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// usageRow is one row of the usage_records dataset
+type usageRow struct {
+	Timestamp      time.Time `parquet:"timestamp,timestamp(millisecond)"`
+	ID             string    `parquet:"id"`
+	RequestID      string    `parquet:"request_id"`
+	APIKeyID       string    `parquet:"api_key_id"`
+	APIKeyName     string    `parquet:"api_key_name"`
+	Model          string    `parquet:"model"`
+	Provider       string    `parquet:"provider"`
+	InputTokens    int64     `parquet:"input_tokens"`
+	OutputTokens   int64     `parquet:"output_tokens"`
+	TotalTokens    int64     `parquet:"total_tokens"`
+	ThinkingTokens int64     `parquet:"thinking_tokens"`
+	CostUSD        float64   `parquet:"cost_usd"`
+	LatencyMs      int64     `parquet:"latency_ms"`
+	Success        bool      `parquet:"success"`
+	ToolCalls      int32     `parquet:"tool_calls"`
+}
+
+var usageHeader = []string{
+	"timestamp", "id", "request_id", "api_key_id", "api_key_name", "model", "provider",
+	"input_tokens", "output_tokens", "total_tokens", "thinking_tokens", "cost_usd",
+	"latency_ms", "success", "tool_calls",
+}
+
+func (r usageRow) csvRecord() []string {
+	return []string{
+		r.Timestamp.Format(time.RFC3339Nano),
+		r.ID,
+		r.RequestID,
+		r.APIKeyID,
+		r.APIKeyName,
+		r.Model,
+		r.Provider,
+		strconv.FormatInt(r.InputTokens, 10),
+		strconv.FormatInt(r.OutputTokens, 10),
+		strconv.FormatInt(r.TotalTokens, 10),
+		strconv.FormatInt(r.ThinkingTokens, 10),
+		strconv.FormatFloat(r.CostUSD, 'f', 6, 64),
+		strconv.FormatInt(r.LatencyMs, 10),
+		strconv.FormatBool(r.Success),
+		strconv.Itoa(int(r.ToolCalls)),
+	}
+}
+
+// requestLogRow is one row of the request_logs dataset
+type requestLogRow struct {
+	Timestamp    time.Time `parquet:"timestamp,timestamp(millisecond)"`
+	ID           string    `parquet:"id"`
+	RequestID    string    `parquet:"request_id"`
+	APIKeyID     string    `parquet:"api_key_id"`
+	Model        string    `parquet:"model"`
+	Provider     string    `parquet:"provider"`
+	Success      bool      `parquet:"success"`
+	ErrorCode    string    `parquet:"error_code"`
+	ErrorMessage string    `parquet:"error_message"`
+	LatencyMs    int64     `parquet:"latency_ms"`
+	Metadata     string    `parquet:"metadata,json"`
+}
+
+var requestLogHeader = []string{
+	"timestamp", "id", "request_id", "api_key_id", "model", "provider",
+	"success", "error_code", "error_message", "latency_ms", "metadata",
+}
+
+func (r requestLogRow) csvRecord() []string {
+	return []string{
+		r.Timestamp.Format(time.RFC3339Nano),
+		r.ID,
+		r.RequestID,
+		r.APIKeyID,
+		r.Model,
+		r.Provider,
+		strconv.FormatBool(r.Success),
+		r.ErrorCode,
+		r.ErrorMessage,
+		strconv.FormatInt(r.LatencyMs, 10),
+		r.Metadata,
+	}
+}
+
+func toUsageRows(records []*domain.UsageRecord) []usageRow {
+	rows := make([]usageRow, len(records))
+	for i, r := range records {
+		rows[i] = usageRow{
+			Timestamp:      r.Timestamp.UTC(),
+			ID:             r.ID,
+			RequestID:      r.RequestID,
+			APIKeyID:       r.APIKeyID,
+			APIKeyName:     r.APIKeyName,
+			Model:          r.Model,
+			Provider:       string(r.Provider),
+			InputTokens:    r.InputTokens,
+			OutputTokens:   r.OutputTokens,
+			TotalTokens:    r.TotalTokens,
+			ThinkingTokens: r.ThinkingTokens,
+			CostUSD:        r.CostUSD,
+			LatencyMs:      r.LatencyMs,
+			Success:        r.Success,
+			ToolCalls:      r.ToolCalls,
+		}
+	}
+	return rows
+}
+
+func toRequestLogRows(records []*domain.UsageRecord) []requestLogRow {
+	rows := make([]requestLogRow, len(records))
+	for i, r := range records {
+		metadata := "{}"
+		if len(r.Metadata) > 0 {
+			if b, err := json.Marshal(r.Metadata); err == nil {
+				metadata = string(b)
+			}
+		}
+		rows[i] = requestLogRow{
+			Timestamp:    r.Timestamp.UTC(),
+			ID:           r.ID,
+			RequestID:    r.RequestID,
+			APIKeyID:     r.APIKeyID,
+			Model:        r.Model,
+			Provider:     string(r.Provider),
+			Success:      r.Success,
+			ErrorCode:    r.ErrorCode,
+			ErrorMessage: r.ErrorMessage,
+			LatencyMs:    r.LatencyMs,
+			Metadata:     metadata,
+		}
+	}
+	return rows
+}
+
+// encode serializes records as a dataset in the given format
+func encode(dataset, format string, records []*domain.UsageRecord) ([]byte, error) {
+	switch dataset {
+	case domain.UsageExportDatasetUsageRecords:
+		return encodeRows(format, usageHeader, toUsageRows(records))
+	case domain.UsageExportDatasetRequestLogs:
+		return encodeRows(format, requestLogHeader, toRequestLogRows(records))
+	default:
+		return nil, fmt.Errorf("unknown dataset %q", dataset)
+	}
+}
+
+func encodeRows[T interface{ csvRecord() []string }](format string, header []string, rows []T) ([]byte, error) {
+	var buf bytes.Buffer
+	switch format {
+	case "parquet", "":
+		w := parquet.NewGenericWriter[T](&buf, parquet.Compression(&parquet.Snappy))
+		if _, err := w.Write(rows); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+	case "csv":
+		cw := csv.NewWriter(&buf)
+		cw.Write(header)
+		for _, row := range rows {
+			cw.Write(row.csvRecord())
+		}
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported export format %q", format)
+	}
+	return buf.Bytes(), nil
+}
+
+func contentType(format string) string {
+	if format == "csv" {
+		return "text/csv"
+	}
+	return "application/vnd.apache.parquet"
+}
@@ -0,0 +1,258 @@
+// Package usageexport writes usage data to object storage on a schedule, one
+// file per completed time partition, for loading into a data warehouse.
+package usageexport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+	"modelgate/internal/objectstore"
+)
+
+// Store is the persistence used by the service (implemented by postgres.TenantStore)
+type Store interface {
+	ListUsageRecordsForExport(ctx context.Context, start, end time.Time) ([]*domain.UsageRecord, error)
+	EarliestUsageRecordTime(ctx context.Context) (*time.Time, error)
+	ListUsageExportStates(ctx context.Context) (map[string]*domain.UsageExportState, error)
+	SaveUsageExportState(ctx context.Context, state *domain.UsageExportState) error
+}
+
+// Result describes one partition file written by an export
+type Result struct {
+	Dataset        string
+	PartitionStart time.Time
+	PartitionEnd   time.Time
+	ObjectKey      string // Empty if the partition had no rows and nothing was written
+	Rows           int
+}
+
+// Service exports completed partitions and keeps a checkpoint per dataset
+type Service struct {
+	config config.UsageExportConfig
+	store  Store
+	dest   objectstore.Store
+
+	mu sync.Mutex // Serializes scheduled and manual runs
+}
+
+// NewService creates a new usage export service
+func NewService(cfg config.UsageExportConfig, store Store, dest objectstore.Store) *Service {
+	return &Service{config: cfg, store: store, dest: dest}
+}
+
+// Config returns the export settings the service was started with
+func (s *Service) Config() config.UsageExportConfig {
+	return s.config
+}
+
+// Datasets returns the datasets this service exports
+func (s *Service) Datasets() []string {
+	datasets := []string{domain.UsageExportDatasetUsageRecords}
+	if s.config.IncludeRequestLogs {
+		datasets = append(datasets, domain.UsageExportDatasetRequestLogs)
+	}
+	return datasets
+}
+
+// States returns the checkpoint of every exported dataset, including ones
+// that have not run yet
+func (s *Service) States(ctx context.Context) ([]*domain.UsageExportState, error) {
+	stored, err := s.store.ListUsageExportStates(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list usage export checkpoints: %w", err)
+	}
+	states := make([]*domain.UsageExportState, 0, len(s.Datasets()))
+	for _, dataset := range s.Datasets() {
+		state := stored[dataset]
+		if state == nil {
+			state = &domain.UsageExportState{Dataset: dataset}
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+// Run exports every completed partition after each dataset's checkpoint. A
+// dataset that has never been exported starts at its oldest record.
+func (s *Service) Run(ctx context.Context) ([]Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	states, err := s.States(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cutoff := s.cutoff()
+
+	var results []Result
+	var errs []error
+	for _, state := range states {
+		start := state.ExportedUntil
+		if start == nil {
+			earliest, err := s.store.EarliestUsageRecordTime(ctx)
+			if err != nil {
+				return results, fmt.Errorf("find earliest usage record: %w", err)
+			}
+			if earliest == nil {
+				continue // Nothing recorded yet
+			}
+			first := s.partitionStart(*earliest)
+			start = &first
+		}
+
+		for p := *start; !s.nextPartition(p).After(cutoff); p = s.nextPartition(p) {
+			result, err := s.exportPartition(ctx, state.Dataset, p)
+			now := time.Now()
+			state.LastRunAt = &now
+			if err != nil {
+				state.LastError = err.Error()
+				errs = append(errs, fmt.Errorf("%s: %w", state.Dataset, err))
+			} else {
+				end := result.PartitionEnd
+				state.ExportedUntil = &end
+				state.LastObjectKey = result.ObjectKey
+				state.LastRowCount = int64(result.Rows)
+				state.LastError = ""
+				results = append(results, result)
+			}
+			if saveErr := s.store.SaveUsageExportState(ctx, state); saveErr != nil {
+				return results, fmt.Errorf("save %s checkpoint: %w", state.Dataset, saveErr)
+			}
+			if err != nil {
+				break // Retry from the same partition on the next run
+			}
+		}
+	}
+	return results, errors.Join(errs...)
+}
+
+// ExportRange re-exports the completed partitions overlapping [from, to),
+// overwriting files written earlier. Checkpoints are left unchanged.
+func (s *Service) ExportRange(ctx context.Context, from, to time.Time) ([]Result, error) {
+	if !from.Before(to) {
+		return nil, fmt.Errorf("export range start must be before its end")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff := s.cutoff()
+	var results []Result
+	for _, dataset := range s.Datasets() {
+		for p := s.partitionStart(from); p.Before(to) && !s.nextPartition(p).After(cutoff); p = s.nextPartition(p) {
+			result, err := s.exportPartition(ctx, dataset, p)
+			if err != nil {
+				return results, fmt.Errorf("%s: %w", dataset, err)
+			}
+			results = append(results, result)
+		}
+	}
+	return results, nil
+}
+
+// StartScheduler exports completed partitions now and then on every interval
+// until ctx is cancelled
+func (s *Service) StartScheduler(ctx context.Context) {
+	interval := s.config.Interval
+	if interval <= 0 {
+		interval = 15 * time.Minute
+	}
+
+	run := func() {
+		results, err := s.Run(ctx)
+		if err != nil {
+			slog.Error("Usage export failed", "error", err)
+		}
+		for _, r := range results {
+			if r.ObjectKey != "" {
+				slog.Info("Exported usage partition", "dataset", r.Dataset, "key", r.ObjectKey, "rows", r.Rows)
+			}
+		}
+	}
+
+	go func() {
+		run()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				run()
+			}
+		}
+	}()
+}
+
+// exportPartition writes one partition of a dataset. Empty partitions are
+// skipped so the warehouse only sees files with rows.
+func (s *Service) exportPartition(ctx context.Context, dataset string, start time.Time) (Result, error) {
+	end := s.nextPartition(start)
+	result := Result{Dataset: dataset, PartitionStart: start, PartitionEnd: end}
+
+	records, err := s.store.ListUsageRecordsForExport(ctx, start, end)
+	if err != nil {
+		return result, fmt.Errorf("list usage records: %w", err)
+	}
+	if len(records) == 0 {
+		return result, nil
+	}
+
+	data, err := encode(dataset, s.config.Format, records)
+	if err != nil {
+		return result, fmt.Errorf("encode %s: %w", s.config.Format, err)
+	}
+	key := s.objectKey(dataset, start)
+	if err := s.dest.Put(ctx, key, data, contentType(s.config.Format)); err != nil {
+		return result, err
+	}
+
+	result.ObjectKey = key
+	result.Rows = len(records)
+	return result, nil
+}
+
+// cutoff is the latest partition end that may be exported: partitions stay
+// open for Delay after they end so late usage writes are included
+func (s *Service) cutoff() time.Time {
+	return time.Now().Add(-s.config.Delay)
+}
+
+func (s *Service) hourly() bool {
+	return s.config.Partition == "hourly"
+}
+
+func (s *Service) partitionStart(t time.Time) time.Time {
+	t = t.UTC()
+	if s.hourly() {
+		return t.Truncate(time.Hour)
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func (s *Service) nextPartition(start time.Time) time.Time {
+	if s.hourly() {
+		return start.Add(time.Hour)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// objectKey lays files out in Hive-style partitions, e.g.
+// usage_records/dt=2025-01-31/hour=13/usage_records-20250131T13.parquet
+func (s *Service) objectKey(dataset string, start time.Time) string {
+	ext := "parquet"
+	if s.config.Format == "csv" {
+		ext = "csv"
+	}
+	if s.hourly() {
+		return fmt.Sprintf("%s/dt=%s/hour=%s/%s-%s.%s", dataset, start.Format("2006-01-02"), start.Format("15"), dataset, start.Format("20060102T15"), ext)
+	}
+	return fmt.Sprintf("%s/dt=%s/%s-%s.%s", dataset, start.Format("2006-01-02"), dataset, start.Format("20060102"), ext)
+}
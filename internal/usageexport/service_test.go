@@ -0,0 +1,143 @@
+package usageexport
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"modelgate/internal/config"
+	"modelgate/internal/domain"
+	"modelgate/internal/objectstore"
+)
+
+// memStore is an in-memory Store for tests
+type memStore struct {
+	records []*domain.UsageRecord
+	states  map[string]*domain.UsageExportState
+}
+
+func (m *memStore) ListUsageRecordsForExport(_ context.Context, start, end time.Time) ([]*domain.UsageRecord, error) {
+	var out []*domain.UsageRecord
+	for _, r := range m.records {
+		if !r.Timestamp.Before(start) && r.Timestamp.Before(end) {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+func (m *memStore) EarliestUsageRecordTime(context.Context) (*time.Time, error) {
+	var earliest *time.Time
+	for _, r := range m.records {
+		if earliest == nil || r.Timestamp.Before(*earliest) {
+			t := r.Timestamp
+			earliest = &t
+		}
+	}
+	return earliest, nil
+}
+
+func (m *memStore) ListUsageExportStates(context.Context) (map[string]*domain.UsageExportState, error) {
+	out := make(map[string]*domain.UsageExportState, len(m.states))
+	for k, v := range m.states {
+		copied := *v
+		out[k] = &copied
+	}
+	return out, nil
+}
+
+func (m *memStore) SaveUsageExportState(_ context.Context, state *domain.UsageExportState) error {
+	copied := *state
+	m.states[state.Dataset] = &copied
+	return nil
+}
+
+// memObjects records every object written
+type memObjects map[string][]byte
+
+func (m memObjects) Put(_ context.Context, key string, data []byte, _ string) error {
+	m[key] = data
+	return nil
+}
+
+func (m memObjects) PutStream(ctx context.Context, key string, body io.Reader, _ int64, contentType string) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	return m.Put(ctx, key, data, contentType)
+}
+
+func (m memObjects) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	data, ok := m[key]
+	if !ok {
+		return nil, objectstore.ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (m memObjects) Delete(_ context.Context, key string) error {
+	delete(m, key)
+	return nil
+}
+
+func TestRunExportsCompletedPartitionsOnce(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	thisHour := now.Truncate(time.Hour)
+	store := &memStore{
+		records: []*domain.UsageRecord{
+			{ID: "a", Model: "gpt-4o", Provider: domain.ProviderOpenAI, TotalTokens: 10, Success: true, Timestamp: thisHour.Add(-3*time.Hour + time.Minute)},
+			{ID: "b", Model: "gpt-4o", Provider: domain.ProviderOpenAI, ErrorCode: "rate_limited", Timestamp: thisHour.Add(-time.Hour + time.Minute)},
+			{ID: "c", Model: "gpt-4o", Provider: domain.ProviderOpenAI, Timestamp: thisHour.Add(time.Second)}, // Open partition
+		},
+		states: map[string]*domain.UsageExportState{},
+	}
+	objects := memObjects{}
+	svc := NewService(config.UsageExportConfig{Format: "csv", Partition: "hourly", IncludeRequestLogs: true}, store, objects)
+
+	results, err := svc.Run(ctx)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(objects) != 4 {
+		t.Fatalf("expected 2 non-empty partitions for each of 2 datasets, got %d objects: %v", len(objects), results)
+	}
+
+	first := thisHour.Add(-3 * time.Hour)
+	key := "usage_records/dt=" + first.Format("2006-01-02") + "/hour=" + first.Format("15") + "/usage_records-" + first.Format("20060102T15") + ".csv"
+	data, ok := objects[key]
+	if !ok {
+		t.Fatalf("expected object %s, got %v", key, objects)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 2 || !strings.Contains(lines[1], ",a,") {
+		t.Fatalf("expected header and row a, got %q", data)
+	}
+	for _, d := range []string{domain.UsageExportDatasetUsageRecords, domain.UsageExportDatasetRequestLogs} {
+		if s := store.states[d]; s == nil || s.ExportedUntil == nil || !s.ExportedUntil.Equal(thisHour) {
+			t.Fatalf("expected %s checkpoint at %v, got %+v", d, thisHour, s)
+		}
+	}
+
+	// Nothing new is complete, so a second run writes nothing
+	delete(objects, key)
+	if results, err := svc.Run(ctx); err != nil || len(results) != 0 || len(objects) != 3 {
+		t.Fatalf("expected no re-export, got %v %v", results, err)
+	}
+
+	// A manual range export rewrites the partition without moving the checkpoint
+	if _, err := svc.ExportRange(ctx, first, first.Add(time.Hour)); err != nil {
+		t.Fatalf("ExportRange failed: %v", err)
+	}
+	if _, ok := objects[key]; !ok || !store.states[domain.UsageExportDatasetUsageRecords].ExportedUntil.Equal(thisHour) {
+		t.Fatal("expected range export to rewrite the partition and keep the checkpoint")
+	}
+
+	data, err = encode(domain.UsageExportDatasetRequestLogs, "parquet", store.records)
+	if err != nil || !bytes.HasPrefix(data, []byte("PAR1")) {
+		t.Fatalf("expected a parquet file, got %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+-- ModelGate - Resumable file uploads
+-- Large files are uploaded as numbered parts, verified, and stitched into a single file
+
+-- =============================================================================
+-- Files Table
+-- Completed, stitched files available to the files API
+-- =============================================================================
+CREATE TABLE IF NOT EXISTS files (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    api_key_id UUID REFERENCES api_keys(id) ON DELETE SET NULL,
+    filename VARCHAR(500) NOT NULL,
+    purpose VARCHAR(50) NOT NULL,
+    mime_type VARCHAR(255),
+    bytes BIGINT NOT NULL DEFAULT 0,
+    sha256 VARCHAR(64) NOT NULL,
+    storage_path TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'processed',  -- processed, deleted
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_files_api_key ON files(api_key_id);
+CREATE INDEX IF NOT EXISTS idx_files_purpose ON files(purpose);
+CREATE INDEX IF NOT EXISTS idx_files_created ON files(created_at);
+
+-- =============================================================================
+-- Uploads Table
+-- In-progress multipart uploads (one row per upload session)
+-- =============================================================================
+CREATE TABLE IF NOT EXISTS uploads (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    api_key_id UUID REFERENCES api_keys(id) ON DELETE SET NULL,
+    filename VARCHAR(500) NOT NULL,
+    purpose VARCHAR(50) NOT NULL,
+    mime_type VARCHAR(255),
+    bytes BIGINT NOT NULL,                              -- Declared total size
+    sha256 VARCHAR(64),                                 -- Optional expected checksum of the whole file
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',      -- pending, completed, cancelled, expired
+    file_id UUID REFERENCES files(id) ON DELETE SET NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_uploads_status ON uploads(status);
+CREATE INDEX IF NOT EXISTS idx_uploads_expires ON uploads(expires_at) WHERE status = 'pending';
+
+-- =============================================================================
+-- Upload Parts Table
+-- Parts received for an upload; re-sending a part number replaces it
+-- =============================================================================
+CREATE TABLE IF NOT EXISTS upload_parts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    upload_id UUID NOT NULL REFERENCES uploads(id) ON DELETE CASCADE,
+    part_number INTEGER NOT NULL,
+    bytes BIGINT NOT NULL,
+    sha256 VARCHAR(64) NOT NULL,                        -- Also returned to clients as the part ETag
+    storage_path TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(upload_id, part_number)
+);
+
+CREATE INDEX IF NOT EXISTS idx_upload_parts_upload ON upload_parts(upload_id);
+
+DROP TRIGGER IF EXISTS update_uploads_updated_at ON uploads;
+CREATE TRIGGER update_uploads_updated_at BEFORE UPDATE ON uploads FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,34 @@
+-- ModelGate - Batch jobs
+-- Batch jobs are executed through providers' native (discounted) batch APIs
+
+-- =============================================================================
+-- Batches Table
+-- One row per ModelGate batch; provider_batch_id links to the provider's job
+-- =============================================================================
+CREATE TABLE IF NOT EXISTS batches (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    api_key_id UUID REFERENCES api_keys(id) ON DELETE SET NULL,
+    endpoint VARCHAR(100) NOT NULL DEFAULT '/v1/chat/completions',
+    input_file_id UUID REFERENCES files(id) ON DELETE SET NULL,
+    output_file_id UUID REFERENCES files(id) ON DELETE SET NULL,
+    completion_window VARCHAR(20) NOT NULL DEFAULT '24h',
+    status VARCHAR(20) NOT NULL DEFAULT 'validating',  -- validating, in_progress, finalizing, completed, failed, expired, cancelling, cancelled
+    provider VARCHAR(50) NOT NULL,
+    model VARCHAR(255) NOT NULL,
+    provider_batch_id VARCHAR(255),
+    total_requests INTEGER NOT NULL DEFAULT 0,
+    completed_requests INTEGER NOT NULL DEFAULT 0,
+    failed_requests INTEGER NOT NULL DEFAULT 0,
+    errors TEXT,
+    metadata JSONB DEFAULT '{}',
+    completed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_batches_api_key ON batches(api_key_id);
+CREATE INDEX IF NOT EXISTS idx_batches_status ON batches(status);
+CREATE INDEX IF NOT EXISTS idx_batches_created ON batches(created_at);
+
+DROP TRIGGER IF EXISTS update_batches_updated_at ON batches;
+CREATE TRIGGER update_batches_updated_at BEFORE UPDATE ON batches FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
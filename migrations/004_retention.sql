@@ -0,0 +1,32 @@
+-- ModelGate - Data retention
+-- Expired usage and audit rows are soft-deleted (optionally after being archived
+-- to object storage) and purged once the soft-delete grace period has passed.
+
+-- =============================================================================
+-- Soft-delete columns
+-- =============================================================================
+ALTER TABLE usage_records ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_usage_records_deleted ON usage_records(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_audit_logs_deleted ON audit_logs(deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- =============================================================================
+-- Retention Policies Table
+-- Per-table overrides of the [retention.tables] defaults in config.toml
+-- (NULL means "use the config value") plus the result of the last janitor run
+-- =============================================================================
+CREATE TABLE IF NOT EXISTS retention_policies (
+    table_name VARCHAR(100) PRIMARY KEY,
+    retention_days INTEGER,
+    archive_before_delete BOOLEAN,
+    enabled BOOLEAN,
+    last_run_at TIMESTAMP WITH TIME ZONE,
+    last_archived_count BIGINT NOT NULL DEFAULT 0,
+    last_deleted_count BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+DROP TRIGGER IF EXISTS update_retention_policies_updated_at ON retention_policies;
+CREATE TRIGGER update_retention_policies_updated_at BEFORE UPDATE ON retention_policies FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,7 @@
+-- ModelGate - Concurrency policies
+-- Per-role request priority and in-flight limits enforced by the dispatcher.
+
+-- =============================================================================
+-- Role Policies
+-- =============================================================================
+ALTER TABLE role_policies ADD COLUMN IF NOT EXISTS concurrency_policy JSONB DEFAULT '{}';
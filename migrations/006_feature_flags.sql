@@ -0,0 +1,38 @@
+-- ModelGate - Feature flags
+-- Flags gate new gateway behaviors during rollout. Each flag has a tenant-wide
+-- value and optional per-role overrides; every change is kept in a changelog so
+-- behavior changes can be correlated with incidents.
+
+-- =============================================================================
+-- Feature Flags Table
+-- Only flags that have been set are stored; the others use their built-in default
+-- =============================================================================
+CREATE TABLE IF NOT EXISTS feature_flags (
+    key VARCHAR(100) PRIMARY KEY,
+    enabled BOOLEAN NOT NULL,
+    role_overrides JSONB NOT NULL DEFAULT '{}',  -- role ID -> enabled
+    updated_by VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+DROP TRIGGER IF EXISTS update_feature_flags_updated_at ON feature_flags;
+CREATE TRIGGER update_feature_flags_updated_at BEFORE UPDATE ON feature_flags FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- =============================================================================
+-- Feature Flag Changelog
+-- =============================================================================
+CREATE TABLE IF NOT EXISTS feature_flag_changes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    flag_key VARCHAR(100) NOT NULL,
+    role_id UUID REFERENCES roles(id) ON DELETE SET NULL,  -- NULL for the tenant-wide value
+    enabled BOOLEAN,                                       -- NULL when a role override was cleared
+    previous_enabled BOOLEAN,
+    note TEXT,
+    actor_id VARCHAR(255),
+    actor_email VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_feature_flag_changes_created ON feature_flag_changes(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_feature_flag_changes_flag ON feature_flag_changes(flag_key, created_at DESC);
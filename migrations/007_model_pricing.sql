@@ -0,0 +1,23 @@
+-- ModelGate - Model pricing history
+-- Every price the gateway has used is kept with the date it took effect, so
+-- costs can be explained after prices change. Rows are only ever appended.
+
+-- =============================================================================
+-- Model Prices Table
+-- source: 'catalog' (bundled), 'provider' (models endpoint) or 'override' (admin)
+-- =============================================================================
+CREATE TABLE IF NOT EXISTS model_prices (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    provider VARCHAR(50) NOT NULL,
+    model_id VARCHAR(255) NOT NULL,
+    input_cost_per_1m DECIMAL(12, 6) NOT NULL DEFAULT 0,
+    output_cost_per_1m DECIMAL(12, 6) NOT NULL DEFAULT 0,
+    source VARCHAR(20) NOT NULL,
+    reset BOOLEAN NOT NULL DEFAULT FALSE,  -- Override rows only: ends the previous override
+    effective_from TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    note TEXT,
+    created_by VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_model_prices_model ON model_prices(provider, model_id, effective_from DESC);
@@ -0,0 +1,21 @@
+-- ModelGate - Scheduled usage export
+-- One checkpoint per exported dataset. exported_until is the end of the last
+-- partition written to object storage; the next run continues from there.
+
+-- =============================================================================
+-- Usage Exports Table
+-- dataset: 'usage_records' or 'request_logs'
+-- =============================================================================
+CREATE TABLE IF NOT EXISTS usage_exports (
+    dataset VARCHAR(50) PRIMARY KEY,
+    exported_until TIMESTAMP WITH TIME ZONE,
+    last_run_at TIMESTAMP WITH TIME ZONE,
+    last_object_key TEXT,
+    last_row_count BIGINT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+DROP TRIGGER IF EXISTS update_usage_exports_updated_at ON usage_exports;
+CREATE TRIGGER update_usage_exports_updated_at BEFORE UPDATE ON usage_exports FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
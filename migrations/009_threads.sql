@@ -0,0 +1,38 @@
+-- ModelGate - Conversation threads
+-- Threads hold the history of a conversation so clients can send only the new
+-- turn. message_count and size_bytes are kept in step with thread_messages so
+-- limits can be checked without reading the messages.
+
+-- =============================================================================
+-- Threads Table
+-- =============================================================================
+CREATE TABLE IF NOT EXISTS threads (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    api_key_id UUID REFERENCES api_keys(id) ON DELETE CASCADE,
+    metadata JSONB NOT NULL DEFAULT '{}',
+    message_count INTEGER NOT NULL DEFAULT 0,
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,   -- Pushed back on every append
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_threads_api_key ON threads(api_key_id);
+CREATE INDEX IF NOT EXISTS idx_threads_expires_at ON threads(expires_at);
+
+DROP TRIGGER IF EXISTS update_threads_updated_at ON threads;
+CREATE TRIGGER update_threads_updated_at BEFORE UPDATE ON threads FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- =============================================================================
+-- Thread Messages Table
+-- message: the domain message (role, content blocks, tool calls) as JSON
+-- =============================================================================
+CREATE TABLE IF NOT EXISTS thread_messages (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    thread_id UUID NOT NULL REFERENCES threads(id) ON DELETE CASCADE,
+    seq INTEGER NOT NULL,
+    role VARCHAR(20) NOT NULL,
+    message JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (thread_id, seq)
+);
@@ -0,0 +1,12 @@
+-- ModelGate - Network access controls
+-- Per-API-key and per-role client IP allowlists, and per-role mTLS requirement.
+
+-- =============================================================================
+-- API Keys
+-- =============================================================================
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS allowed_cidrs JSONB DEFAULT '[]';
+
+-- =============================================================================
+-- Role Policies
+-- =============================================================================
+ALTER TABLE role_policies ADD COLUMN IF NOT EXISTS network_policy JSONB DEFAULT '{}';
@@ -0,0 +1,12 @@
+-- ModelGate - Provider API key canary rollout
+-- A key with traffic_percent set takes that share of its priority group's
+-- traffic; keys without one split the rest. A key whose recent error rate
+-- reaches rollback_error_rate is set back to 0% automatically.
+
+-- =============================================================================
+-- Provider API Keys
+-- =============================================================================
+ALTER TABLE provider_api_keys ADD COLUMN IF NOT EXISTS traffic_percent INTEGER CHECK (traffic_percent BETWEEN 0 AND 100);
+ALTER TABLE provider_api_keys ADD COLUMN IF NOT EXISTS rollback_error_rate DECIMAL(4,3) DEFAULT 0;
+ALTER TABLE provider_api_keys ADD COLUMN IF NOT EXISTS rolled_back_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE provider_api_keys ADD COLUMN IF NOT EXISTS rollback_reason TEXT;
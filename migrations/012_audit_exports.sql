@@ -0,0 +1,29 @@
+-- ModelGate - Audit log export
+-- Asynchronous CSV/JSONL exports of the audit log. Finished files go to the
+-- configured object store, or are kept in content for download until expires_at.
+
+-- =============================================================================
+-- Audit Export Jobs Table
+-- status: 'pending', 'running', 'completed' or 'failed'
+-- =============================================================================
+CREATE TABLE IF NOT EXISTS audit_export_jobs (
+    id UUID PRIMARY KEY,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    format VARCHAR(10) NOT NULL,
+    filter JSONB NOT NULL DEFAULT '{}',
+    requested_by VARCHAR(255),
+    row_count BIGINT NOT NULL DEFAULT 0,
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    object_key TEXT,
+    content BYTEA,
+    download_token VARCHAR(64),
+    error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    completed_at TIMESTAMP WITH TIME ZONE,
+    expires_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_export_jobs_created ON audit_export_jobs(created_at DESC);
+
+-- Keyset pagination for exports walks the log oldest first
+CREATE INDEX IF NOT EXISTS idx_audit_logs_timestamp_id ON audit_logs(timestamp, id);
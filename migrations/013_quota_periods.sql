@@ -0,0 +1,40 @@
+-- ModelGate - Billing period quotas
+-- Limits apply per calendar month (UTC). When a period ends its usage is
+-- snapshotted and a new period opens with the configured limits.
+
+-- =============================================================================
+-- Quota Limits Table (single row; 0 = unlimited)
+-- =============================================================================
+CREATE TABLE IF NOT EXISTS quota_limits (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    requests_limit BIGINT NOT NULL DEFAULT 0,
+    tokens_limit BIGINT NOT NULL DEFAULT 0,
+    cost_limit_usd DECIMAL(12, 4) NOT NULL DEFAULT 0,
+    updated_by VARCHAR(255),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- =============================================================================
+-- Quota Periods Table
+-- The *_limit columns are the period's effective (prorated) limits; the *_used
+-- columns are filled in when the period is closed.
+-- =============================================================================
+CREATE TABLE IF NOT EXISTS quota_periods (
+    id UUID PRIMARY KEY,
+    period_start TIMESTAMP WITH TIME ZONE NOT NULL UNIQUE,
+    period_end TIMESTAMP WITH TIME ZONE NOT NULL,
+    requests_limit BIGINT NOT NULL DEFAULT 0,
+    tokens_limit BIGINT NOT NULL DEFAULT 0,
+    cost_limit_usd DECIMAL(12, 4) NOT NULL DEFAULT 0,
+    requests_used BIGINT NOT NULL DEFAULT 0,
+    tokens_used BIGINT NOT NULL DEFAULT 0,
+    cost_used_usd DECIMAL(12, 6) NOT NULL DEFAULT 0,
+    closed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_quota_periods_open ON quota_periods(period_start) WHERE closed_at IS NULL;
+
+DROP TRIGGER IF EXISTS update_quota_periods_updated_at ON quota_periods;
+CREATE TRIGGER update_quota_periods_updated_at BEFORE UPDATE ON quota_periods FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,31 @@
+-- ModelGate - pgvector-backed MCP tool search
+-- Tool embeddings are copied into a vector column with an HNSW index so that
+-- tool search runs as an approximate nearest-neighbour query in Postgres.
+-- Everything here is skipped when the pgvector extension isn't available;
+-- tool search then keeps computing similarity in the gateway.
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_available_extensions WHERE name = 'vector') THEN
+        RAISE NOTICE 'pgvector is not installed, MCP tool search will run in memory';
+        RETURN;
+    END IF;
+    CREATE EXTENSION IF NOT EXISTS vector;
+
+    -- Same dimensions as semantic_cache.embedding (nomic-embed-text)
+    ALTER TABLE mcp_tools ADD COLUMN IF NOT EXISTS combined_embedding_vector vector(768);
+
+    -- Backfill from the CSV-encoded embeddings
+    UPDATE mcp_tools
+       SET combined_embedding_vector = ('[' || combined_embedding || ']')::vector
+     WHERE combined_embedding IS NOT NULL
+       AND combined_embedding != ''
+       AND combined_embedding_vector IS NULL
+       AND array_length(string_to_array(combined_embedding, ','), 1) = 768;
+
+    CREATE INDEX IF NOT EXISTS idx_mcp_tools_embedding_vector
+        ON mcp_tools USING hnsw (combined_embedding_vector vector_cosine_ops);
+EXCEPTION
+    WHEN insufficient_privilege THEN
+        RAISE NOTICE 'Not allowed to create the pgvector extension, MCP tool search will run in memory';
+END $$;
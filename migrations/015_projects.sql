@@ -0,0 +1,34 @@
+-- ModelGate - Projects
+-- A project groups API keys under the tenant (typically one per team) with its
+-- own budget and usage rollups. Usage records keep the project they were
+-- billed to, so deleting a project or moving a key does not rewrite history.
+
+-- =============================================================================
+-- Projects Table
+-- budget_policy: same shape as a role's budget policy
+-- =============================================================================
+CREATE TABLE IF NOT EXISTS projects (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(255) NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    budget_policy JSONB NOT NULL DEFAULT '{}',
+    created_by UUID,
+    created_by_email VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+DROP TRIGGER IF EXISTS update_projects_updated_at ON projects;
+CREATE TRIGGER update_projects_updated_at BEFORE UPDATE ON projects FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- =============================================================================
+-- API Keys
+-- =============================================================================
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS project_id UUID REFERENCES projects(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_api_keys_project ON api_keys(project_id);
+
+-- =============================================================================
+-- Usage Records (no foreign key: rollups outlive the project)
+-- =============================================================================
+ALTER TABLE usage_records ADD COLUMN IF NOT EXISTS project_id UUID;
+CREATE INDEX IF NOT EXISTS idx_usage_records_project ON usage_records(project_id, created_at) WHERE project_id IS NOT NULL;
@@ -0,0 +1,11 @@
+-- ModelGate - Provider API key validation
+-- Admins can test a key against its provider ("test connection"). The latest
+-- result is kept on the key so the UI can show when it was last known to work.
+
+-- =============================================================================
+-- Provider API Keys
+-- =============================================================================
+ALTER TABLE provider_api_keys ADD COLUMN IF NOT EXISTS last_validated_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE provider_api_keys ADD COLUMN IF NOT EXISTS last_validation_ok BOOLEAN;
+ALTER TABLE provider_api_keys ADD COLUMN IF NOT EXISTS last_validation_error TEXT;
+ALTER TABLE provider_api_keys ADD COLUMN IF NOT EXISTS last_validation_latency_ms INTEGER;
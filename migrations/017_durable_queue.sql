@@ -0,0 +1,30 @@
+-- ModelGate - Durable dispatcher queue
+-- With [server] durable_queue enabled, non-streaming requests accepted by the
+-- dispatcher are stored here until processed, so a restart picks them up
+-- again. A worker claims a row (queued -> processing) before calling the
+-- provider, so a request is executed at most once across instances. Clients
+-- that retry with the same Idempotency-Key get the stored response.
+
+-- =============================================================================
+-- Dispatch Queue Table
+-- status: queued, processing, completed, failed
+-- =============================================================================
+CREATE TABLE IF NOT EXISTS dispatch_queue (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    idempotency_key VARCHAR(255) NOT NULL,
+    api_key_id VARCHAR(255) NOT NULL DEFAULT '',
+    role_id VARCHAR(255) NOT NULL DEFAULT '',
+    group_id VARCHAR(255) NOT NULL DEFAULT '',
+    priority INTEGER NOT NULL DEFAULT 5,
+    request JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'queued',
+    response JSONB,
+    error TEXT,
+    enqueued_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    started_at TIMESTAMP WITH TIME ZONE,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    UNIQUE (api_key_id, idempotency_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_dispatch_queue_status ON dispatch_queue(status, priority DESC, enqueued_at);
+CREATE INDEX IF NOT EXISTS idx_dispatch_queue_completed ON dispatch_queue(completed_at) WHERE completed_at IS NOT NULL;
@@ -0,0 +1,28 @@
+-- ModelGate - Custom models on OpenAI-compatible endpoints
+-- Admins register models served by their own vLLM, TGI or other
+-- OpenAI-compatible servers under the "custom" provider. Each model has its
+-- own endpoint; the provider's API keys, if any, are sent to all of them.
+-- Registered models are synced into available_models so they are listed,
+-- routed and covered by policies like any other provider's models.
+
+-- =============================================================================
+-- Custom Models Table
+-- =============================================================================
+CREATE TABLE IF NOT EXISTS custom_models (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    model_id VARCHAR(255) NOT NULL UNIQUE,
+    name VARCHAR(255) NOT NULL DEFAULT '',
+    base_url VARCHAR(1000) NOT NULL,
+    upstream_model VARCHAR(255) NOT NULL DEFAULT '',
+    context_window INTEGER NOT NULL DEFAULT 0,
+    max_output_tokens INTEGER NOT NULL DEFAULT 0,
+    input_cost_per_1m DECIMAL(10, 6) NOT NULL DEFAULT 0,
+    output_cost_per_1m DECIMAL(10, 6) NOT NULL DEFAULT 0,
+    supports_tools BOOLEAN NOT NULL DEFAULT FALSE,
+    supports_vision BOOLEAN NOT NULL DEFAULT FALSE,
+    supports_reasoning BOOLEAN NOT NULL DEFAULT FALSE,
+    supports_streaming BOOLEAN NOT NULL DEFAULT TRUE,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
@@ -0,0 +1,13 @@
+-- ModelGate - Scheduled usage digest emails
+-- Users can subscribe to a daily or weekly email summarizing spend, top
+-- models, top API keys, error rate and budget burn, either for the whole
+-- tenant or for the API keys of one role. Preferences live on the user.
+
+-- =============================================================================
+-- Digest Subscriptions
+-- =============================================================================
+ALTER TABLE users ADD COLUMN IF NOT EXISTS digest_frequency VARCHAR(10) NOT NULL DEFAULT 'off';  -- off, daily, weekly
+ALTER TABLE users ADD COLUMN IF NOT EXISTS digest_role_id UUID REFERENCES roles(id) ON DELETE SET NULL;  -- NULL covers the whole tenant
+ALTER TABLE users ADD COLUMN IF NOT EXISTS digest_last_sent_at TIMESTAMP WITH TIME ZONE;  -- Scheduled time of the last digest sent
+
+CREATE INDEX IF NOT EXISTS idx_users_digest_frequency ON users(digest_frequency) WHERE digest_frequency <> 'off';
@@ -0,0 +1,14 @@
+-- ModelGate - Load balancing across provider API keys
+-- Each provider picks among its keys with a strategy (round_robin,
+-- least_errors or weighted, stored in the provider's extra_settings). A key
+-- that gets a 429 or 401 is skipped until its cooldown passes, and token and
+-- cost usage is accounted per key.
+
+-- =============================================================================
+-- Provider API Keys
+-- =============================================================================
+ALTER TABLE provider_api_keys ADD COLUMN IF NOT EXISTS cooldown_until TIMESTAMP WITH TIME ZONE;
+ALTER TABLE provider_api_keys ADD COLUMN IF NOT EXISTS cooldown_reason VARCHAR(20);  -- rate_limit, auth_error
+ALTER TABLE provider_api_keys ADD COLUMN IF NOT EXISTS input_tokens BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE provider_api_keys ADD COLUMN IF NOT EXISTS output_tokens BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE provider_api_keys ADD COLUMN IF NOT EXISTS cost_usd DECIMAL(14, 6) NOT NULL DEFAULT 0;
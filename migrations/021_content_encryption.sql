@@ -0,0 +1,14 @@
+-- ModelGate - Encryption of captured prompts and responses
+-- When MODELGATE_ENCRYPTION_KEY is set, captured content in usage records and
+-- the semantic cache is encrypted with the tenant's data key. The data key is
+-- stored here sealed in an envelope with the master key, so rotating the master
+-- key only re-wraps it.
+
+-- =============================================================================
+-- Content Keys
+-- =============================================================================
+CREATE TABLE IF NOT EXISTS content_keys (
+    key_id VARCHAR(64) PRIMARY KEY,   -- Written into every sealed value
+    envelope JSONB NOT NULL,          -- Data key sealed with the master key
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
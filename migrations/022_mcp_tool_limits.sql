@@ -0,0 +1,12 @@
+-- ModelGate - MCP tool invocation limits and budgets
+-- Role MCP policies can cap tool calls per minute and per day, set per-tool
+-- cooldowns and charge paid tools against daily and monthly budgets. Limits
+-- are enforced from mcp_tool_executions, which now records each call's cost;
+-- calls that break a limit are recorded with status BLOCKED.
+
+-- =============================================================================
+-- MCP Tool Executions
+-- =============================================================================
+ALTER TABLE mcp_tool_executions ADD COLUMN IF NOT EXISTS cost_usd DECIMAL(12, 6) NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_mcp_tool_executions_role_started ON mcp_tool_executions(role_id, started_at);
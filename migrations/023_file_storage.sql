@@ -0,0 +1,20 @@
+-- ModelGate - Files API storage backends and retention
+-- Files uploaded via /v1/files are kept on local disk, in an object store, or
+-- only with a provider's native files API. Files can expire under the role's
+-- file policy or the [files] retention, after which they are marked deleted.
+
+-- =============================================================================
+-- Files
+-- =============================================================================
+ALTER TABLE files ADD COLUMN IF NOT EXISTS storage VARCHAR(20) NOT NULL DEFAULT 'local';  -- local, object_store, provider
+ALTER TABLE files ADD COLUMN IF NOT EXISTS provider VARCHAR(50);                        -- Provider holding a passthrough file
+ALTER TABLE files ADD COLUMN IF NOT EXISTS provider_file_id TEXT;                       -- The provider's file ID or URI
+ALTER TABLE files ADD COLUMN IF NOT EXISTS model VARCHAR(255);                          -- Model whose provider client uploaded it
+ALTER TABLE files ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_files_expires ON files(expires_at) WHERE status = 'processed' AND expires_at IS NOT NULL;
+
+-- =============================================================================
+-- Role Policies
+-- =============================================================================
+ALTER TABLE role_policies ADD COLUMN IF NOT EXISTS file_policy JSONB DEFAULT '{}';
@@ -0,0 +1,31 @@
+-- ModelGate - Self-service registration
+-- People can ask for an account from the login page. Requests wait in a
+-- review queue until an admin approves them, which creates the user, or
+-- rejects them with a reason. Passwords are stored hashed.
+
+-- =============================================================================
+-- Registration Requests
+-- =============================================================================
+CREATE TABLE IF NOT EXISTS registration_requests (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_name VARCHAR(255) NOT NULL,
+    organization_email VARCHAR(255) NOT NULL,
+    admin_name VARCHAR(255) NOT NULL,
+    admin_email VARCHAR(255) NOT NULL,              -- Email of the account to create
+    password_hash VARCHAR(255) NOT NULL,
+    slug VARCHAR(100) NOT NULL,
+    preferred_tier VARCHAR(50),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',  -- pending, approved, rejected
+    rejection_reason TEXT,
+    user_role VARCHAR(50),                          -- Role the account was created with
+    reviewed_at TIMESTAMP WITH TIME ZONE,
+    reviewed_by VARCHAR(255),                       -- Email of the reviewing admin
+    requested_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_registration_requests_status ON registration_requests(status, requested_at);
+-- One open request per email
+CREATE UNIQUE INDEX IF NOT EXISTS idx_registration_requests_pending_email
+    ON registration_requests(lower(admin_email)) WHERE status = 'pending';
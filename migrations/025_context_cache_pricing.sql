@@ -0,0 +1,14 @@
+-- ModelGate - Context cache pricing
+-- Providers such as DeepSeek bill prompt tokens served from their context
+-- cache at a discount. Model prices carry the cached input price, and usage
+-- records keep how many input tokens were cache hits.
+
+-- =============================================================================
+-- Cached input price
+-- =============================================================================
+ALTER TABLE model_prices ADD COLUMN IF NOT EXISTS cached_input_cost_per_1m DECIMAL(12, 6) NOT NULL DEFAULT 0;
+
+-- =============================================================================
+-- Cached input tokens per request
+-- =============================================================================
+ALTER TABLE usage_records ADD COLUMN IF NOT EXISTS cached_input_tokens BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,13 @@
+-- ModelGate - Multi-region providers
+-- Providers such as Azure OpenAI and Bedrock can be configured with several
+-- regional deployments that requests fail over between. Usage records keep
+-- the region that served each request, for latency analysis per region.
+
+-- =============================================================================
+-- Serving region per request
+-- =============================================================================
+ALTER TABLE usage_records ADD COLUMN IF NOT EXISTS provider_region VARCHAR(64);
+
+CREATE INDEX IF NOT EXISTS idx_usage_records_provider_region
+    ON usage_records (provider, provider_region, created_at)
+    WHERE provider_region IS NOT NULL;
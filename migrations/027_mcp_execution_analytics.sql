@@ -0,0 +1,11 @@
+-- ModelGate - MCP tool execution analytics
+-- Execution counts, error rates and p95 durations per tool, server and role
+-- are aggregated from mcp_tool_executions over a time range, and the
+-- execution log can be browsed by tool, role, API key and status.
+
+-- =============================================================================
+-- MCP Tool Executions
+-- =============================================================================
+CREATE INDEX IF NOT EXISTS idx_mcp_tool_executions_started ON mcp_tool_executions(started_at);
+CREATE INDEX IF NOT EXISTS idx_mcp_tool_executions_tool_started ON mcp_tool_executions(tool_id, started_at);
+CREATE INDEX IF NOT EXISTS idx_mcp_tool_executions_api_key_started ON mcp_tool_executions(api_key_id, started_at);
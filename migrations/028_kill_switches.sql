@@ -0,0 +1,18 @@
+-- ModelGate - Provider and model kill switches
+-- During incidents admins can stop traffic to a provider or a single model
+-- at runtime. Routing avoids switched-off targets and direct requests to
+-- them fail with a 503 until the switch is cleared or expires. Every change
+-- is recorded in the audit log.
+
+-- =============================================================================
+-- Kill Switches
+-- =============================================================================
+CREATE TABLE IF NOT EXISTS kill_switches (
+    provider VARCHAR(50) NOT NULL,
+    model VARCHAR(255) NOT NULL DEFAULT '',         -- Empty for the whole provider
+    reason TEXT NOT NULL DEFAULT '',
+    disabled_by VARCHAR(255),                       -- Email of the admin who set it
+    disabled_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE,            -- Traffic resumes at this time
+    PRIMARY KEY (provider, model)
+);
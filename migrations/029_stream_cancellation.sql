@@ -0,0 +1,9 @@
+-- ModelGate - Client disconnects
+-- Streams are cancelled at the provider as soon as the client disconnects.
+-- Usage records flag those requests so the partial spend can be told apart
+-- from provider failures.
+
+-- =============================================================================
+-- Client disconnect flag per request
+-- =============================================================================
+ALTER TABLE usage_records ADD COLUMN IF NOT EXISTS cancelled_by_client BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,11 @@
+-- ModelGate - API key budgets
+-- Each API key can have daily and monthly soft and hard spending limits on
+-- top of its role's and project's budgets. Spend is summed from usage records.
+
+-- =============================================================================
+-- Spending caps per key (NULL = none)
+-- =============================================================================
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS budget JSONB;
+
+CREATE INDEX IF NOT EXISTS idx_usage_records_api_key_created
+    ON usage_records (api_key_id, created_at);
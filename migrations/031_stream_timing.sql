@@ -0,0 +1,9 @@
+-- ModelGate - Streaming latency
+-- Average latency hides how streams behave: a slow first token and a slow
+-- token rate feel very different to users. Streamed requests record both.
+
+-- =============================================================================
+-- Time to first token and throughput per request (NULL for non-streamed requests)
+-- =============================================================================
+ALTER TABLE usage_records ADD COLUMN IF NOT EXISTS time_to_first_token_ms INTEGER;
+ALTER TABLE usage_records ADD COLUMN IF NOT EXISTS tokens_per_second DOUBLE PRECISION;
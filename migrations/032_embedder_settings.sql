@@ -0,0 +1,20 @@
+-- ModelGate - Embedder per feature
+-- The semantic cache and MCP tool search can each use their own embedder,
+-- for instance a self-hosted Ollama model for the cache and OpenAI for tool
+-- search. Features without a row use the [embedder] section of config.toml.
+-- Changing a feature's model or dimensions clears its stored vectors and
+-- resizes the vector column.
+
+-- =============================================================================
+-- Embedder Settings
+-- =============================================================================
+CREATE TABLE IF NOT EXISTS embedder_settings (
+    feature VARCHAR(50) PRIMARY KEY,                -- semantic_cache, tool_search
+    type VARCHAR(50) NOT NULL,                      -- ollama, openai
+    model VARCHAR(255) NOT NULL,
+    base_url TEXT NOT NULL DEFAULT '',
+    api_key TEXT NOT NULL DEFAULT '',
+    dimensions INTEGER NOT NULL,
+    updated_by VARCHAR(255),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,55 @@
+-- ModelGate - Assistants API (subset)
+-- Assistants hold a model, instructions and tools; runs execute an assistant
+-- on a stored thread (see 009_threads.sql) through the chat pipeline. Tools
+-- and pending tool calls are stored as JSON in the domain format.
+
+-- =============================================================================
+-- Assistants Table
+-- =============================================================================
+CREATE TABLE IF NOT EXISTS assistants (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    api_key_id UUID REFERENCES api_keys(id) ON DELETE CASCADE,
+    name VARCHAR(256) NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT '',
+    model VARCHAR(255) NOT NULL,
+    instructions TEXT NOT NULL DEFAULT '',
+    tools JSONB NOT NULL DEFAULT '[]',
+    temperature REAL,
+    metadata JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_assistants_api_key ON assistants(api_key_id);
+
+DROP TRIGGER IF EXISTS update_assistants_updated_at ON assistants;
+CREATE TRIGGER update_assistants_updated_at BEFORE UPDATE ON assistants FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- =============================================================================
+-- Assistant Runs Table
+-- required_tool_calls: function calls the client must answer (requires_action)
+-- =============================================================================
+CREATE TABLE IF NOT EXISTS assistant_runs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    thread_id UUID NOT NULL REFERENCES threads(id) ON DELETE CASCADE,
+    assistant_id UUID NOT NULL REFERENCES assistants(id) ON DELETE CASCADE,
+    api_key_id UUID REFERENCES api_keys(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'queued',  -- queued, in_progress, requires_action, cancelling, cancelled, failed, completed, incomplete, expired
+    model VARCHAR(255) NOT NULL,
+    instructions TEXT NOT NULL DEFAULT '',
+    tools JSONB NOT NULL DEFAULT '[]',
+    temperature REAL,
+    required_tool_calls JSONB,
+    last_error JSONB,
+    prompt_tokens BIGINT NOT NULL DEFAULT 0,
+    completion_tokens BIGINT NOT NULL DEFAULT 0,
+    metadata JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    started_at TIMESTAMP WITH TIME ZONE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    failed_at TIMESTAMP WITH TIME ZONE,
+    cancelled_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_assistant_runs_thread ON assistant_runs(thread_id, created_at);
@@ -0,0 +1,21 @@
+-- ModelGate - Prompt injection corpus
+-- Known jailbreak and prompt injection phrasings. Prompts are embedded with
+-- the injection_detection embedder and compared with the nearest patterns;
+-- roles set the similarity that counts as a detection in their ML detection
+-- settings. Builtin patterns are seeded at start; admins add their own.
+-- The vector column follows the embedder's dimensions (see 032).
+
+-- =============================================================================
+-- Injection Patterns Table
+-- =============================================================================
+CREATE TABLE IF NOT EXISTS injection_patterns (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    text TEXT NOT NULL UNIQUE,
+    category VARCHAR(50) NOT NULL DEFAULT 'injection',  -- injection, jailbreak
+    builtin BOOLEAN NOT NULL DEFAULT FALSE,
+    embedding vector(768),                              -- NULL until embedded
+    created_by VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_injection_patterns_embedding ON injection_patterns USING hnsw (embedding vector_cosine_ops);
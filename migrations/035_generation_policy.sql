@@ -0,0 +1,8 @@
+-- ModelGate - Per-role generation parameter policies
+-- Roles can set default temperature, top_p and max_tokens for requests that
+-- leave them out, and caps that client-supplied values are clamped to.
+
+-- =============================================================================
+-- Role Policies
+-- =============================================================================
+ALTER TABLE role_policies ADD COLUMN IF NOT EXISTS generation_policy JSONB DEFAULT '{}';
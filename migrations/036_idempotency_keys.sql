@@ -0,0 +1,23 @@
+-- ModelGate - Idempotency keys
+-- Chat completions and responses sent with an Idempotency-Key header claim the
+-- key for their API key. The response is stored so retries replay it instead
+-- of reaching the provider again; rows are deleted once expired.
+
+-- =============================================================================
+-- Idempotency Keys Table
+-- =============================================================================
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    api_key_id UUID NOT NULL REFERENCES api_keys(id) ON DELETE CASCADE,
+    key VARCHAR(255) NOT NULL,
+    request_hash VARCHAR(64) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'in_progress',  -- in_progress, completed
+    status_code INTEGER NOT NULL DEFAULT 0,
+    header JSONB NOT NULL DEFAULT '{}',
+    body BYTEA,
+    locked_until TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (api_key_id, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires ON idempotency_keys(expires_at);
@@ -0,0 +1,27 @@
+-- ModelGate - MCP tool approvals
+-- A role calling an MCP tool it has no permission for queues a request for
+-- admins instead of being silently denied. One row per role and tool; repeat
+-- calls while the request is open only bump its count.
+
+-- =============================================================================
+-- MCP Tool Approvals Table
+-- =============================================================================
+CREATE TABLE IF NOT EXISTS mcp_tool_approvals (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    role_id UUID NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    server_id UUID NOT NULL REFERENCES mcp_servers(id) ON DELETE CASCADE,
+    tool_id UUID NOT NULL REFERENCES mcp_tools(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',  -- pending, approved, denied
+    requested_by UUID,                               -- API key of the first call
+    request_count INTEGER NOT NULL DEFAULT 1,
+    first_requested_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_requested_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    visibility VARCHAR(20),                          -- Granted on approval: ALLOW or SEARCH
+    decided_by UUID,
+    decided_by_email VARCHAR(255),
+    decided_at TIMESTAMP WITH TIME ZONE,
+    decision_reason TEXT,
+    UNIQUE(role_id, tool_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_mcp_tool_approvals_status ON mcp_tool_approvals(status, first_requested_at);
@@ -0,0 +1,22 @@
+-- ModelGate - Personal API keys
+-- Dashboard users can create their own API keys. A personal key has no role or
+-- group of its own: it uses the RBAC role an admin assigned to its owner, stops
+-- working while the owner is deactivated and is revoked when they are. Usage
+-- records keep the owner so analytics can attribute spend to users.
+
+-- =============================================================================
+-- Users
+-- =============================================================================
+ALTER TABLE users ADD COLUMN IF NOT EXISTS api_role_id UUID REFERENCES roles(id) ON DELETE SET NULL;  -- Role of the user's personal keys
+
+-- =============================================================================
+-- API Keys
+-- =============================================================================
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS owner_user_id UUID REFERENCES users(id) ON DELETE CASCADE;
+CREATE INDEX IF NOT EXISTS idx_api_keys_owner ON api_keys(owner_user_id) WHERE owner_user_id IS NOT NULL;
+
+-- =============================================================================
+-- Usage Records (no foreign key: rollups outlive the user)
+-- =============================================================================
+ALTER TABLE usage_records ADD COLUMN IF NOT EXISTS user_id UUID;
+CREATE INDEX IF NOT EXISTS idx_usage_records_user ON usage_records(user_id, created_at) WHERE user_id IS NOT NULL;
@@ -0,0 +1,48 @@
+-- ModelGate - Data-plane audit events
+-- audit_logs records control-plane changes. With data-plane audit turned on,
+-- the gateway also writes one compact event per /v1 request: the API key,
+-- model, policy decision, tools allowed or removed, and the cache and routing
+-- outcome. Events are partitioned by month so expired months are dropped
+-- whole; the gateway creates partitions ahead of time.
+
+-- =============================================================================
+-- Data-Plane Audit Settings (one row; no row means disabled)
+-- =============================================================================
+CREATE TABLE IF NOT EXISTS data_plane_audit_settings (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    store_events BOOLEAN NOT NULL DEFAULT TRUE,     -- Write events to data_plane_audit_events
+    webhook_url TEXT NOT NULL DEFAULT '',           -- Batches of events are posted here
+    updated_by VARCHAR(255),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- =============================================================================
+-- Data-Plane Audit Events
+-- =============================================================================
+CREATE TABLE IF NOT EXISTS data_plane_audit_events (
+    id UUID NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    request_id VARCHAR(255) NOT NULL DEFAULT '',
+    method VARCHAR(10) NOT NULL,
+    path TEXT NOT NULL,
+    status_code INTEGER NOT NULL,
+    latency_ms BIGINT NOT NULL DEFAULT 0,
+    api_key_id UUID,                                -- No foreign key: events outlive the key
+    role_id VARCHAR(255) NOT NULL DEFAULT '',
+    project_id VARCHAR(255) NOT NULL DEFAULT '',
+    client_ip VARCHAR(64) NOT NULL DEFAULT '',
+    model VARCHAR(255) NOT NULL DEFAULT '',         -- As requested
+    served_model VARCHAR(255) NOT NULL DEFAULT '',  -- After routing and context management
+    policy_decision VARCHAR(20) NOT NULL DEFAULT '', -- allowed, blocked
+    policy_code VARCHAR(100) NOT NULL DEFAULT '',   -- Violation code of a blocked request
+    tools_allowed TEXT[] NOT NULL DEFAULT '{}',
+    tools_removed TEXT[] NOT NULL DEFAULT '{}',
+    params_clamped TEXT[] NOT NULL DEFAULT '{}',
+    cache_status VARCHAR(20) NOT NULL DEFAULT '',   -- hit, miss
+    routing_strategy VARCHAR(50) NOT NULL DEFAULT '',
+    PRIMARY KEY (id, created_at)
+) PARTITION BY RANGE (created_at);
+
+CREATE INDEX IF NOT EXISTS idx_data_plane_audit_events_created ON data_plane_audit_events(created_at);
+CREATE INDEX IF NOT EXISTS idx_data_plane_audit_events_key ON data_plane_audit_events(api_key_id, created_at);
@@ -0,0 +1,38 @@
+-- ModelGate - Event streaming outbox
+-- With [event_stream] enabled, usage records, policy violation events and
+-- audit logs are published to Kafka or NATS. A trigger copies each new row
+-- into the outbox in the same transaction as the insert; a publisher sends
+-- the rows oldest first and marks them published once the broker acknowledged
+-- them, so every event is delivered at least once. The gateway creates the
+-- triggers of the configured event types at startup and drops the others.
+
+-- =============================================================================
+-- Event Outbox
+-- =============================================================================
+CREATE TABLE IF NOT EXISTS event_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    event_type VARCHAR(50) NOT NULL,                -- usage, policy_violation, audit
+    payload JSONB NOT NULL,                         -- The row as inserted
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    published_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_outbox_pending ON event_outbox(id) WHERE published_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_event_outbox_published ON event_outbox(published_at) WHERE published_at IS NOT NULL;
+
+-- Trigger function; the event type is the trigger's argument. Captured
+-- prompts and responses stay out of usage events.
+CREATE OR REPLACE FUNCTION enqueue_outbox_event() RETURNS TRIGGER AS $$
+DECLARE
+    payload JSONB := to_jsonb(NEW);
+BEGIN
+    IF TG_TABLE_NAME = 'usage_records' AND jsonb_typeof(payload->'metadata') = 'object' THEN
+        payload := jsonb_set(payload, '{metadata}', (payload->'metadata') - 'request' - 'response');
+    END IF;
+    INSERT INTO event_outbox (event_type, payload) VALUES (TG_ARGV[0], payload);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
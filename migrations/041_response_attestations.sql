@@ -0,0 +1,38 @@
+-- ModelGate - Signed response attestations
+-- With [attestation] enabled, every successful chat completion is signed with
+-- an Ed25519 key over the hash of the request body, the hash of the response
+-- body, the version of the policies enforced and the signing time. The
+-- signature is returned in the X-ModelGate-Attestation header and kept here
+-- next to the usage record, so an auditor can later prove what a model said
+-- under which policy. Keys are rotated; retired keys stay to verify what they
+-- signed.
+
+-- =============================================================================
+-- Attestation Keys
+-- =============================================================================
+CREATE TABLE IF NOT EXISTS attestation_keys (
+    id VARCHAR(32) PRIMARY KEY,                     -- Fingerprint of the public key
+    public_key BYTEA NOT NULL,
+    private_key BYTEA NOT NULL,                     -- Ed25519 seed, sealed in an envelope when encryption is configured
+    created_by VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    retired_at TIMESTAMP WITH TIME ZONE             -- Set when rotated out; the key no longer signs
+);
+
+-- At most one key signs at a time
+CREATE UNIQUE INDEX IF NOT EXISTS idx_attestation_keys_active ON attestation_keys((retired_at IS NULL)) WHERE retired_at IS NULL;
+
+-- =============================================================================
+-- Response Attestations
+-- =============================================================================
+CREATE TABLE IF NOT EXISTS response_attestations (
+    request_id VARCHAR(255) PRIMARY KEY,            -- usage_records.request_id
+    key_id VARCHAR(32) NOT NULL REFERENCES attestation_keys(id),
+    request_hash CHAR(64) NOT NULL,                 -- Hex SHA-256 of the request body
+    response_hash CHAR(64) NOT NULL,                -- Hex SHA-256 of the response body
+    policy_version VARCHAR(64) NOT NULL,
+    signed_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    signature BYTEA NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_response_attestations_signed_at ON response_attestations(signed_at);
@@ -0,0 +1,14 @@
+-- ModelGate - Model deprecation and sunset dates
+-- Providers announce when they retire a model. Admins record the sunset date
+-- and the model that replaces it on the model's catalog entry. Requests for a
+-- deprecated model get an X-ModelGate-Deprecation header, roles can opt in to
+-- having them moved to the replacement, and admins are alerted ahead of each
+-- sunset. Catalog refreshes leave these columns alone.
+
+ALTER TABLE available_models ADD COLUMN IF NOT EXISTS sunset_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE available_models ADD COLUMN IF NOT EXISTS replacement_model VARCHAR(255);
+ALTER TABLE available_models ADD COLUMN IF NOT EXISTS deprecation_notice TEXT;
+-- When admins were last alerted about the sunset; cleared when it changes
+ALTER TABLE available_models ADD COLUMN IF NOT EXISTS sunset_alerted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_available_models_sunset ON available_models(sunset_at) WHERE sunset_at IS NOT NULL;
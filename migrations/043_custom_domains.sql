@@ -0,0 +1,34 @@
+-- ModelGate - Custom domains
+-- Tenants can be served on their own hostnames (api.customer.com). Each
+-- domain names the tenant it resolves to and how its TLS certificate is
+-- obtained: issued through ACME, or uploaded by an admin. With host
+-- enforcement on, requests for hostnames that are neither registered here nor
+-- in [custom_domains] allowed_hosts are refused.
+
+-- =============================================================================
+-- Custom Domains
+-- =============================================================================
+CREATE TABLE IF NOT EXISTS custom_domains (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    hostname VARCHAR(253) NOT NULL UNIQUE,          -- Lowercase, without port
+    tenant_slug VARCHAR(255) NOT NULL DEFAULT 'default',
+    cert_source VARCHAR(16) NOT NULL DEFAULT 'acme', -- "acme" or "uploaded"
+    cert_pem TEXT,                                  -- Uploaded certificate chain
+    key_pem BYTEA,                                  -- Uploaded private key, sealed in an envelope when encryption is configured
+    cert_expires_at TIMESTAMP WITH TIME ZONE,       -- Expiry of the uploaded certificate
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- =============================================================================
+-- ACME Cache
+-- =============================================================================
+-- The ACME account key and issued certificates, shared by every instance so
+-- a certificate is issued once and survives restarts
+CREATE TABLE IF NOT EXISTS acme_cache (
+    key VARCHAR(255) PRIMARY KEY,
+    data BYTEA NOT NULL,                            -- Sealed in an envelope when encryption is configured
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,43 @@
+-- ModelGate - Documents
+-- Files ingested through /v1/documents for retrieval: their text is
+-- extracted (with OCR for images and scanned pages), split into chunks and
+-- embedded with the documents embedder. Chat requests reference documents by
+-- id or collection and are grounded in the most similar chunks.
+-- The vector column follows the embedder's dimensions (see 032).
+
+-- =============================================================================
+-- Documents
+-- =============================================================================
+CREATE TABLE IF NOT EXISTS documents (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    collection VARCHAR(255) NOT NULL DEFAULT 'default',
+    filename VARCHAR(1024) NOT NULL,
+    mime_type VARCHAR(255) NOT NULL,
+    bytes BIGINT NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'processing', -- processing, ready, failed
+    error TEXT,                                      -- Why processing failed
+    pages INTEGER NOT NULL DEFAULT 0,
+    ocr_pages INTEGER NOT NULL DEFAULT 0,            -- Pages or images transcribed by the OCR model
+    chunks INTEGER NOT NULL DEFAULT 0,
+    api_key_id VARCHAR(255),                         -- Uploading key; NULL for admin uploads, visible to every key
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_documents_collection ON documents(collection);
+CREATE INDEX IF NOT EXISTS idx_documents_api_key ON documents(api_key_id);
+
+-- =============================================================================
+-- Document Chunks
+-- =============================================================================
+CREATE TABLE IF NOT EXISTS document_chunks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    document_id UUID NOT NULL REFERENCES documents(id) ON DELETE CASCADE,
+    chunk_index INTEGER NOT NULL,
+    page INTEGER NOT NULL DEFAULT 0,                 -- 1-based page the chunk starts on, 0 when the file has no pages
+    text TEXT NOT NULL,
+    embedding vector(768),                           -- NULL until embedded
+    UNIQUE (document_id, chunk_index)
+);
+
+CREATE INDEX IF NOT EXISTS idx_document_chunks_embedding ON document_chunks USING hnsw (embedding vector_cosine_ops);
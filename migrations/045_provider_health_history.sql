@@ -0,0 +1,26 @@
+-- ModelGate - Provider health history and SLA reports
+-- The health tracker only knows how providers are doing now. With
+-- [health_history] enabled, each instance writes what it saw of every
+-- provider/model per time bucket: requests, failures the provider is
+-- answerable for, latency percentiles and the circuit breaker state. Rows
+-- with an empty model cover the provider as a whole. Instances writing the
+-- same bucket are merged; their percentiles are averaged, weighted by
+-- requests. The GraphQL timeline and the monthly SLA reports read from here.
+
+CREATE TABLE IF NOT EXISTS provider_health_samples (
+    bucket_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    bucket_seconds INTEGER NOT NULL,
+    provider VARCHAR(50) NOT NULL,
+    model VARCHAR(255) NOT NULL DEFAULT '',        -- Empty for the provider as a whole
+    requests BIGINT NOT NULL DEFAULT 0,
+    failures BIGINT NOT NULL DEFAULT 0,            -- Timeouts, overload, rate limits and server errors
+    client_errors BIGINT NOT NULL DEFAULT 0,       -- Refused for the caller's fault; not in requests
+    p50_latency_ms DOUBLE PRECISION NOT NULL DEFAULT 0,
+    p95_latency_ms DOUBLE PRECISION NOT NULL DEFAULT 0,
+    p99_latency_ms DOUBLE PRECISION NOT NULL DEFAULT 0,
+    breaker_state VARCHAR(20) NOT NULL DEFAULT 'closed', -- Worst state in the bucket
+    breaker_open_seconds DOUBLE PRECISION NOT NULL DEFAULT 0,
+    PRIMARY KEY (provider, model, bucket_start)
+);
+
+CREATE INDEX IF NOT EXISTS idx_provider_health_samples_bucket ON provider_health_samples(bucket_start);
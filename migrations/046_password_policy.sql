@@ -0,0 +1,23 @@
+-- ModelGate - Password policy for dashboard users
+-- Passwords were bcrypt hashes with no rules. [passwords] now picks bcrypt or
+-- argon2id, sets length and complexity rules, a max age, how many previous
+-- passwords can't be reused, and locks accounts after failed logins. Each
+-- lockout in a row lasts twice as long as the last, up to a maximum; a
+-- successful login starts over. Admins can unlock users and give them a
+-- temporary password they must change at their next login.
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS password_changed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW();
+ALTER TABLE users ADD COLUMN IF NOT EXISTS must_change_password BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS failed_login_count INTEGER NOT NULL DEFAULT 0; -- Since the last login or lockout
+ALTER TABLE users ADD COLUMN IF NOT EXISTS lockout_count INTEGER NOT NULL DEFAULT 0;      -- Lockouts in a row
+ALTER TABLE users ADD COLUMN IF NOT EXISTS locked_until TIMESTAMP WITH TIME ZONE;
+
+-- Previous password hashes, for passwords.history
+CREATE TABLE IF NOT EXISTS user_password_history (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    password_hash VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_password_history_user ON user_password_history(user_id, created_at DESC);
@@ -0,0 +1,38 @@
+-- ModelGate - Two-factor authentication for dashboard users
+-- Users enroll a TOTP authenticator app and receive single-use backup codes.
+-- Once enrolled, a login needs a code as well as the password. The tenant can
+-- require enrollment of admins or of every user. Failed codes count towards
+-- the same account lockout as failed passwords, but separately, so knowing
+-- the password doesn't reset them.
+
+-- =============================================================================
+-- Two-Factor Settings (one row; no row requires nobody)
+-- =============================================================================
+CREATE TABLE IF NOT EXISTS two_factor_settings (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    require_for_admins BOOLEAN NOT NULL DEFAULT FALSE,
+    require_for_all BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_by VARCHAR(255),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- =============================================================================
+-- User Enrollments
+-- =============================================================================
+CREATE TABLE IF NOT EXISTS user_two_factor (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    secret TEXT NOT NULL,                          -- Base32, or an encryption envelope with encryption configured
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,        -- Set once the first code is confirmed
+    confirmed_at TIMESTAMP WITH TIME ZONE,
+    last_used_step BIGINT NOT NULL DEFAULT 0,      -- Codes of this time step or earlier are refused
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS user_backup_codes (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash VARCHAR(64) NOT NULL,                -- SHA-256 of the normalized code
+    used_at TIMESTAMP WITH TIME ZONE,
+    PRIMARY KEY (user_id, code_hash)
+);
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS two_factor_failures INTEGER NOT NULL DEFAULT 0; -- Since the last accepted code or lockout
@@ -0,0 +1,8 @@
+-- ModelGate - Per-role output transformation
+-- Roles can strip reasoning, citation markers and markdown from responses,
+-- rewrite them with ordered regex rules and cap their length.
+
+-- =============================================================================
+-- Role Policies
+-- =============================================================================
+ALTER TABLE role_policies ADD COLUMN IF NOT EXISTS output_transform_policy JSONB DEFAULT '{}';
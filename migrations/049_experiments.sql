@@ -0,0 +1,33 @@
+-- ModelGate - Experiments
+-- A/B (or N-way) experiments split chat traffic across variants that change
+-- the model or the system prompt. While an experiment runs, requests of its
+-- audience are assigned a variant by weight, and their usage records keep
+-- the experiment and variant so variants can be compared.
+
+-- =============================================================================
+-- Experiments
+-- =============================================================================
+CREATE TABLE IF NOT EXISTS experiments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(255) NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'draft',  -- draft, running, paused, completed
+    model VARCHAR(255) NOT NULL DEFAULT '',       -- Requested model taking part; empty for any model
+    role_ids TEXT[] NOT NULL DEFAULT '{}',        -- Audience; with api_key_ids empty, every request
+    api_key_ids TEXT[] NOT NULL DEFAULT '{}',
+    variants JSONB NOT NULL DEFAULT '[]',         -- [{name, weight, model, system_prompt, system_prompt_mode}]
+    created_by VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMP WITH TIME ZONE,
+    ended_at TIMESTAMP WITH TIME ZONE
+);
+
+-- =============================================================================
+-- Usage Records (no foreign key: results outlive the experiment)
+-- =============================================================================
+ALTER TABLE usage_records ADD COLUMN IF NOT EXISTS experiment_id UUID;
+ALTER TABLE usage_records ADD COLUMN IF NOT EXISTS experiment_variant VARCHAR(255);
+CREATE INDEX IF NOT EXISTS idx_usage_records_experiment
+    ON usage_records (experiment_id, experiment_variant, created_at)
+    WHERE experiment_id IS NOT NULL;
@@ -0,0 +1,33 @@
+-- ModelGate - Response feedback
+-- Clients (POST /v1/feedback) and reviewers (GraphQL) rate responses with
+-- thumbs up or down, a 1-5 rating and a comment. Feedback is linked to the
+-- response's usage record, found by request ID, and keeps the model and role
+-- so it can be summed up per model and role. A response has at most one
+-- feedback; sending it again replaces it.
+
+-- =============================================================================
+-- Feedback
+-- =============================================================================
+CREATE TABLE IF NOT EXISTS response_feedback (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    request_id VARCHAR(100) NOT NULL UNIQUE,
+    usage_record_id UUID NOT NULL REFERENCES usage_records(id) ON DELETE CASCADE,
+    model VARCHAR(255) NOT NULL,
+    provider VARCHAR(50) NOT NULL,
+    role_id UUID REFERENCES roles(id) ON DELETE SET NULL,  -- Role of the request's API key
+    api_key_id UUID REFERENCES api_keys(id) ON DELETE SET NULL,
+    thumbs_up BOOLEAN,                                     -- NULL when not given
+    rating SMALLINT CHECK (rating BETWEEN 1 AND 5),
+    comment TEXT NOT NULL DEFAULT '',
+    submitted_by VARCHAR(255),                             -- Reviewer's email; NULL when the API key sent it
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_response_feedback_usage_record ON response_feedback(usage_record_id);
+CREATE INDEX IF NOT EXISTS idx_response_feedback_created ON response_feedback(created_at);
+
+-- =============================================================================
+-- Usage Records: feedback finds its response by request ID
+-- =============================================================================
+CREATE INDEX IF NOT EXISTS idx_usage_records_request ON usage_records(request_id);
@@ -0,0 +1,31 @@
+-- ModelGate - Fine-tuning jobs
+-- Jobs are run by the provider (OpenAI, Together) on files uploaded through
+-- /v1/files. The gateway keeps a row per job, refreshed by a poller, so jobs
+-- can be listed per API key and audited.
+
+CREATE TABLE IF NOT EXISTS fine_tuning_jobs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    api_key_id UUID REFERENCES api_keys(id) ON DELETE SET NULL,
+    provider VARCHAR(50) NOT NULL,
+    model VARCHAR(255) NOT NULL,
+    training_file_id UUID REFERENCES files(id) ON DELETE SET NULL,
+    validation_file_id UUID REFERENCES files(id) ON DELETE SET NULL,
+    hyperparameters JSONB NOT NULL DEFAULT '{}',
+    suffix VARCHAR(64) NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'validating_files',  -- validating_files, queued, running, succeeded, failed, cancelled
+    provider_job_id VARCHAR(255),
+    fine_tuned_model VARCHAR(255),
+    trained_tokens BIGINT NOT NULL DEFAULT 0,
+    error TEXT,
+    metadata JSONB NOT NULL DEFAULT '{}',
+    finished_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_fine_tuning_jobs_api_key ON fine_tuning_jobs(api_key_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_fine_tuning_jobs_active ON fine_tuning_jobs(status)
+    WHERE status IN ('validating_files', 'queued', 'running');
+
+DROP TRIGGER IF EXISTS update_fine_tuning_jobs_updated_at ON fine_tuning_jobs;
+CREATE TRIGGER update_fine_tuning_jobs_updated_at BEFORE UPDATE ON fine_tuning_jobs FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,6 @@
+-- ModelGate - Dashboard roles
+-- The GraphQL API checks each field against the permissions of the user's
+-- dashboard role (for example keys:read, policies:write, usage:read_own).
+-- Users without one get the built-in permissions of their user role.
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS dashboard_role_id UUID REFERENCES roles(id) ON DELETE SET NULL;
@@ -18,6 +18,8 @@ import TelemetryPage from './pages/tenant/Telemetry'
 import RequestLogsPage from './pages/tenant/RequestLogs'
 import CostAnalysisPage from './pages/tenant/CostAnalysis'
 import AuditLogsPage from './pages/tenant/AuditLogs'
+import FeatureFlagsPage from './pages/tenant/FeatureFlags'
+import PricingPage from './pages/tenant/Pricing'
 import MCPServersPage from './pages/tenant/MCPServers'
 import AdvancedMetricsPage from './pages/tenant/AdvancedMetrics'
 import { AgentDashboardPage } from './pages/tenant/AgentDashboardGraphQL'
@@ -49,6 +51,7 @@ function App() {
             <Route path="advanced-metrics" element={<AdvancedMetricsPage />} />
             <Route path="providers" element={<ProvidersPage />} />
             <Route path="models" element={<ModelsPage />} />
+            <Route path="pricing" element={<PricingPage />} />
             <Route path="roles" element={<RolesPage />} />
             <Route path="api-keys" element={<APIKeysPage />} />
             <Route path="users" element={<UsersPage />} />
@@ -56,6 +59,7 @@ function App() {
             <Route path="telemetry" element={<TelemetryPage />} />
             <Route path="mcp" element={<MCPServersPage />} />
             <Route path="alerts" element={<PlaceholderPage title="Budget Alerts" />} />
+            <Route path="feature-flags" element={<FeatureFlagsPage />} />
             <Route path="settings" element={<SettingsPage />} />
           </Route>
 
@@ -24,6 +24,7 @@ import {
   ADD_PROVIDER_API_KEY,
   UPDATE_PROVIDER_API_KEY,
   DELETE_PROVIDER_API_KEY,
+  VALIDATE_PROVIDER_API_KEY,
 } from '@/graphql/operations'
 import {
   Plus,
@@ -34,6 +35,7 @@ import {
   Activity,
   Clock,
   Loader2,
+  Zap,
 } from 'lucide-react'
 import { cn } from '@/lib/utils'
 
@@ -54,6 +56,16 @@ interface ProviderAPIKey {
   lastUsedAt: string | null
   createdAt: string
   updatedAt: string
+  trafficPercent: number | null
+  rollbackErrorRate: number
+  rolledBackAt: string | null
+  rollbackReason: string | null
+  recentRequests: number
+  recentErrorRate: number
+  lastValidatedAt: string | null
+  lastValidationOk: boolean | null
+  lastValidationError: string | null
+  lastValidationLatencyMs: number | null
 }
 
 interface APIKeyManagerProps {
@@ -315,6 +327,34 @@ function KeyCard({
     },
   })
 
+  const [validateKey, { loading: validating }] = useMutation(VALIDATE_PROVIDER_API_KEY, {
+    onCompleted: (data) => {
+      const result = data.validateProviderAPIKey
+      if (result.valid) {
+        toast({
+          title: `Connection OK (${result.latencyMs}ms)`,
+          description: result.capabilities?.length
+            ? `Capabilities: ${result.capabilities.join(', ')}`
+            : undefined,
+        })
+      } else {
+        toast({
+          title: 'Connection failed',
+          description: result.error || result.errorCode,
+          variant: 'destructive',
+        })
+      }
+      onUpdate()
+    },
+    onError: (error) => {
+      toast({
+        title: 'Failed to test key',
+        description: error.message,
+        variant: 'destructive',
+      })
+    },
+  })
+
   const handleToggleEnabled = () => {
     updateKey({
       variables: {
@@ -376,9 +416,31 @@ function KeyCard({
               {apiKey.credentialType === 'api_key' && (
                 <Badge variant="secondary" className="text-xs">🔑 Key</Badge>
               )}
+              {apiKey.trafficPercent !== null && (
+                <Badge
+                  variant="outline"
+                  className={cn('text-xs', apiKey.rolledBackAt && 'border-red-500 text-red-600')}
+                  title={apiKey.rollbackReason || undefined}
+                >
+                  {apiKey.rolledBackAt ? 'Rolled back' : `${apiKey.trafficPercent}% traffic`}
+                </Badge>
+              )}
               <HealthBadge score={apiKey.healthScore} />
             </div>
             <div className="flex items-center gap-1">
+              <Button
+                size="sm"
+                variant="ghost"
+                title="Test connection"
+                onClick={() => validateKey({ variables: { id: apiKey.id } })}
+                disabled={validating}
+              >
+                {validating ? (
+                  <Loader2 className="h-4 w-4 animate-spin" />
+                ) : (
+                  <Zap className="h-4 w-4" />
+                )}
+              </Button>
               <Button
                 size="sm"
                 variant="ghost"
@@ -435,6 +497,22 @@ function KeyCard({
             </div>
           </div>
 
+          {/* Last Validation */}
+          {apiKey.lastValidatedAt && (
+            <div
+              className={cn(
+                'flex items-center gap-2 text-xs',
+                apiKey.lastValidationOk ? 'text-muted-foreground' : 'text-red-600'
+              )}
+            >
+              {apiKey.lastValidationOk ? <Check className="h-3 w-3" /> : <X className="h-3 w-3" />}
+              Tested {formatRelativeTime(apiKey.lastValidatedAt)}
+              {apiKey.lastValidationOk
+                ? apiKey.lastValidationLatencyMs !== null && ` (${apiKey.lastValidationLatencyMs}ms)`
+                : apiKey.lastValidationError && `: ${apiKey.lastValidationError}`}
+            </div>
+          )}
+
           {/* Rate Limit Warning */}
           {apiKey.rateLimitResetAt &&
             apiKey.rateLimitRemaining !== null &&
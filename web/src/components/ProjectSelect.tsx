@@ -0,0 +1,46 @@
+import { useQuery } from '@apollo/client'
+import {
+  Select,
+  SelectContent,
+  SelectItem,
+  SelectTrigger,
+  SelectValue,
+} from '@/components/ui/select'
+import { GET_PROJECTS } from '@/graphql/operations'
+
+interface ProjectSelectProps {
+  value: string // 'all' or a project ID
+  onChange: (value: string) => void
+}
+
+// Limits dashboard stats to one project's API keys. Hidden while the tenant
+// has no projects, or the user can't read them.
+export function ProjectSelect({ value, onChange }: ProjectSelectProps) {
+  const { data } = useQuery(GET_PROJECTS)
+  const projects: { id: string; name: string }[] = data?.projects || []
+
+  if (projects.length === 0) {
+    return null
+  }
+
+  return (
+    <Select value={value} onValueChange={onChange}>
+      <SelectTrigger className="w-[180px]">
+        <SelectValue placeholder="Project" />
+      </SelectTrigger>
+      <SelectContent>
+        <SelectItem value="all">All projects</SelectItem>
+        {projects.map((project) => (
+          <SelectItem key={project.id} value={project.id}>
+            {project.name}
+          </SelectItem>
+        ))}
+      </SelectContent>
+    </Select>
+  )
+}
+
+// The projectId variable for a ProjectSelect value
+export function projectIdVariable(value: string): string | undefined {
+  return value === 'all' ? undefined : value
+}
@@ -18,6 +18,8 @@ import {
   Plug,
   Gauge,
   Bot,
+  Flag,
+  Tag,
 } from 'lucide-react'
 
 interface NavItem {
@@ -48,6 +50,7 @@ const dashboardNavigation: NavSection[] = [
     items: [
       { title: 'Providers', href: '/dashboard/providers', icon: Server },
       { title: 'Models', href: '/dashboard/models', icon: Layers },
+      { title: 'Model Pricing', href: '/dashboard/pricing', icon: Tag },
       { title: 'MCP Gateway', href: '/dashboard/mcp', icon: Plug },
       { title: 'Telemetry', href: '/dashboard/telemetry', icon: Radio },
     ],
@@ -65,6 +68,7 @@ const dashboardNavigation: NavSection[] = [
     title: 'Settings',
     items: [
       { title: 'Budget Alerts', href: '/dashboard/alerts', icon: Bell },
+      { title: 'Feature Flags', href: '/dashboard/feature-flags', icon: Flag },
       { title: 'Settings', href: '/dashboard/settings', icon: Settings },
     ],
   },
@@ -118,8 +118,13 @@ interface PromptPolicies {
   outputValidation: {
     enabled: boolean
     detectCodeExecution: boolean
+    detectSQLStatements: boolean
+    detectShellCommands: boolean
+    detectHTMLScripts: boolean
     detectSecretLeakage: boolean
     detectPIILeakage: boolean
+    detectSystemPromptLeakage: boolean
+    streamWindowChars: number
     onViolation: string
   }
 }
@@ -132,10 +137,22 @@ interface ToolPolicies {
   requireToolApproval: boolean
 }
 
+interface MCPToolLimit {
+  tool: string
+  cooldownSeconds: number
+  costPerCallUsd: number
+}
+
 interface MCPPolicies {
   enabled: boolean
   allowToolSearch: boolean
   auditToolExecution: boolean
+  listDeniedTools: boolean
+  maxInvocationsPerMinute: number
+  maxInvocationsPerDay: number
+  dailyBudgetUsd: number
+  monthlyBudgetUsd: number
+  toolLimits: MCPToolLimit[]
 }
 
 interface RateLimitPolicy {
@@ -147,7 +164,9 @@ interface RateLimitPolicy {
   tokensPerDay: number
   costPerDayUSD: number
   costPerMonthUSD: number
+  burstMultiplier: number
   burstLimit: number
+  burstTokens: number
 }
 
 interface ModelRestrictions {
@@ -155,6 +174,10 @@ interface ModelRestrictions {
   allowedProviders: string[]
   defaultModel: string
   maxTokensPerRequest: number
+  rewriteDeprecatedModels: boolean
+  allowedEmbeddingModels: string[]
+  maxEmbeddingDimensions: number
+  maxEmbeddingInputs: number
 }
 
 interface CachingPolicy {
@@ -166,12 +189,14 @@ interface CachingPolicy {
   cacheToolCalls: boolean // Deprecated: Backend never caches tool calls (time-dependent)
   excludedModels: string[]
   trackSavings: boolean
+  allowBypass: boolean
 }
 
 interface RoutingPolicy {
   enabled: boolean
   strategy: string
   allowModelOverride: boolean
+  capabilityFallback: boolean
   costConfig?: {
     simpleQueryThreshold: number
     complexQueryThreshold: number
@@ -202,6 +227,14 @@ interface ResiliencePolicy {
   circuitBreakerThreshold: number
   circuitBreakerTimeout: number
   requestTimeoutMs: number
+  contextWindow: {
+    enabled: boolean
+    strategy: string
+    reserveOutputTokens: number
+    keepRecentMessages: number
+    summaryModel: string
+    largerModels: string[]
+  }
 }
 
 interface BudgetPolicy {
@@ -210,6 +243,8 @@ interface BudgetPolicy {
   weeklyLimitUSD: number
   monthlyLimitUSD: number
   maxCostPerRequest: number
+  maxTokensPerConversation: number
+  maxCostPerConversation: number
   alertThreshold: number
   criticalThreshold: number
   alertWebhook: string
@@ -298,8 +333,13 @@ const defaultPolicy: RolePolicy = {
     outputValidation: {
       enabled: true,
       detectCodeExecution: true,
+      detectSQLStatements: false,
+      detectShellCommands: false,
+      detectHTMLScripts: false,
       detectSecretLeakage: true,
       detectPIILeakage: true,
+      detectSystemPromptLeakage: false,
+      streamWindowChars: 0,
       onViolation: 'REDACT',
     },
   },
@@ -314,6 +354,12 @@ const defaultPolicy: RolePolicy = {
     enabled: false,
     allowToolSearch: true,
     auditToolExecution: true,
+    listDeniedTools: false,
+    maxInvocationsPerMinute: 0,
+    maxInvocationsPerDay: 0,
+    dailyBudgetUsd: 0,
+    monthlyBudgetUsd: 0,
+    toolLimits: [],
   },
   rateLimitPolicy: {
     requestsPerMinute: 60,
@@ -324,13 +370,19 @@ const defaultPolicy: RolePolicy = {
     tokensPerDay: 10000000,
     costPerDayUSD: 100,
     costPerMonthUSD: 1000,
+    burstMultiplier: 1,
     burstLimit: 10,
+    burstTokens: 0,
   },
   modelRestrictions: {
     allowedModels: [],
     allowedProviders: [],
     defaultModel: '',
     maxTokensPerRequest: 0,
+    rewriteDeprecatedModels: false,
+    allowedEmbeddingModels: [],
+    maxEmbeddingDimensions: 0,
+    maxEmbeddingInputs: 0,
   },
   cachingPolicy: {
     enabled: false,
@@ -341,11 +393,13 @@ const defaultPolicy: RolePolicy = {
     cacheToolCalls: false,
     excludedModels: [],
     trackSavings: true,
+    allowBypass: false,
   },
   routingPolicy: {
     enabled: false,
     strategy: 'COST',
     allowModelOverride: true,
+    capabilityFallback: false,
   },
   resiliencePolicy: {
     enabled: false,
@@ -361,6 +415,14 @@ const defaultPolicy: RolePolicy = {
     circuitBreakerThreshold: 5,
     circuitBreakerTimeout: 60,
     requestTimeoutMs: 30000,
+    contextWindow: {
+      enabled: false,
+      strategy: 'TRUNCATE',
+      reserveOutputTokens: 0,
+      keepRecentMessages: 0,
+      summaryModel: '',
+      largerModels: [],
+    },
   },
   budgetPolicy: {
     enabled: false,
@@ -368,6 +430,8 @@ const defaultPolicy: RolePolicy = {
     weeklyLimitUSD: 0,
     monthlyLimitUSD: 0,
     maxCostPerRequest: 0,
+    maxTokensPerConversation: 0,
+    maxCostPerConversation: 0,
     alertThreshold: 0.8,
     criticalThreshold: 0.95,
     alertWebhook: '',
@@ -1046,8 +1110,12 @@ function PromptSecurityEditor({
             <div className="space-y-2">
               {[
                 { key: 'detectCodeExecution', label: 'Executable Code' },
+                { key: 'detectSQLStatements', label: 'SQL Statements' },
+                { key: 'detectShellCommands', label: 'Shell Commands' },
+                { key: 'detectHTMLScripts', label: 'HTML Scripts' },
                 { key: 'detectSecretLeakage', label: 'Secret/API Key Leakage' },
                 { key: 'detectPIILeakage', label: 'PII Leakage' },
+                { key: 'detectSystemPromptLeakage', label: 'System Prompt Leakage' },
               ].map((item) => (
                 <div key={item.key} className="flex items-center justify-between">
                   <span className="text-sm">{item.label}</span>
@@ -1094,6 +1162,25 @@ function PromptSecurityEditor({
                 <SelectItem value="REGENERATE">Ask Model to Regenerate</SelectItem>
               </SelectContent>
             </Select>
+            <p className="text-xs text-muted-foreground">
+              Streamed responses that violate the policy are cut off with a policy_violation finish reason,
+              except with Return with Warning or Silent Log.
+            </p>
+            <label className="text-sm font-medium">Stream Scan Window (characters)</label>
+            <Input
+              type="number"
+              placeholder="128"
+              value={promptPolicies.outputValidation.streamWindowChars || ''}
+              onChange={(e) =>
+                onChange({
+                  outputValidation: {
+                    ...promptPolicies.outputValidation,
+                    streamWindowChars: parseInt(e.target.value) || 0,
+                  },
+                })
+              }
+              disabled={readOnly || !promptPolicies.outputValidation.enabled}
+            />
           </div>
         </div>
       </CollapsibleSection>
@@ -1732,6 +1819,131 @@ function MCPPolicyEditor({
                   disabled={readOnly}
                 />
               </div>
+              <div className="flex items-center justify-between p-4 border rounded-lg">
+                <div>
+                  <p className="font-medium">List Denied Tools</p>
+                  <p className="text-sm text-muted-foreground">Show denied tools in tools/list as disabled, with the reason</p>
+                </div>
+                <Switch
+                  checked={mcpPolicies.listDeniedTools}
+                  onCheckedChange={(checked) => onChange({ listDeniedTools: checked })}
+                  disabled={readOnly}
+                />
+              </div>
+            </div>
+
+            {/* Invocation Limits */}
+            <div className="space-y-3">
+              <div>
+                <p className="font-medium">Invocation Limits</p>
+                <p className="text-sm text-muted-foreground">
+                  Caps across all tools for this role. 0 means unlimited. Budgets apply to tools with a cost per call.
+                </p>
+              </div>
+              <div className="grid grid-cols-4 gap-4">
+                <div className="space-y-2">
+                  <label className="text-sm font-medium">Calls per Minute</label>
+                  <Input
+                    type="number"
+                    value={mcpPolicies.maxInvocationsPerMinute}
+                    onChange={(e) => onChange({ maxInvocationsPerMinute: parseInt(e.target.value) || 0 })}
+                    disabled={readOnly}
+                  />
+                </div>
+                <div className="space-y-2">
+                  <label className="text-sm font-medium">Calls per Day</label>
+                  <Input
+                    type="number"
+                    value={mcpPolicies.maxInvocationsPerDay}
+                    onChange={(e) => onChange({ maxInvocationsPerDay: parseInt(e.target.value) || 0 })}
+                    disabled={readOnly}
+                  />
+                </div>
+                <div className="space-y-2">
+                  <label className="text-sm font-medium">Daily Budget ($)</label>
+                  <Input
+                    type="number"
+                    step="0.01"
+                    value={mcpPolicies.dailyBudgetUsd}
+                    onChange={(e) => onChange({ dailyBudgetUsd: parseFloat(e.target.value) || 0 })}
+                    disabled={readOnly}
+                  />
+                </div>
+                <div className="space-y-2">
+                  <label className="text-sm font-medium">Monthly Budget ($)</label>
+                  <Input
+                    type="number"
+                    step="0.01"
+                    value={mcpPolicies.monthlyBudgetUsd}
+                    onChange={(e) => onChange({ monthlyBudgetUsd: parseFloat(e.target.value) || 0 })}
+                    disabled={readOnly}
+                  />
+                </div>
+              </div>
+            </div>
+
+            {/* Per-Tool Limits */}
+            <div className="space-y-3">
+              <div className="flex items-center justify-between">
+                <div>
+                  <p className="font-medium">Per-Tool Limits</p>
+                  <p className="text-sm text-muted-foreground">
+                    Cooldown between calls and cost charged to the budgets, by tool name (server_slug__tool_name)
+                  </p>
+                </div>
+                <Button
+                  variant="outline"
+                  size="sm"
+                  onClick={() =>
+                    onChange({
+                      toolLimits: [...(mcpPolicies.toolLimits || []), { tool: '', cooldownSeconds: 0, costPerCallUsd: 0 }],
+                    })
+                  }
+                  disabled={readOnly}
+                >
+                  <Plus className="h-4 w-4 mr-1" />
+                  Add Limit
+                </Button>
+              </div>
+              {(mcpPolicies.toolLimits || []).map((limit, i) => {
+                const updateLimit = (updates: Partial<MCPToolLimit>) =>
+                  onChange({
+                    toolLimits: mcpPolicies.toolLimits.map((l, j) => (j === i ? { ...l, ...updates } : l)),
+                  })
+                return (
+                  <div key={i} className="grid grid-cols-[2fr_1fr_1fr_auto] gap-2 items-center">
+                    <Input
+                      placeholder="tavily_mcp__search"
+                      value={limit.tool}
+                      onChange={(e) => updateLimit({ tool: e.target.value })}
+                      disabled={readOnly}
+                    />
+                    <Input
+                      type="number"
+                      placeholder="Cooldown (s)"
+                      value={limit.cooldownSeconds}
+                      onChange={(e) => updateLimit({ cooldownSeconds: parseInt(e.target.value) || 0 })}
+                      disabled={readOnly}
+                    />
+                    <Input
+                      type="number"
+                      step="0.001"
+                      placeholder="Cost per call ($)"
+                      value={limit.costPerCallUsd}
+                      onChange={(e) => updateLimit({ costPerCallUsd: parseFloat(e.target.value) || 0 })}
+                      disabled={readOnly}
+                    />
+                    <Button
+                      variant="ghost"
+                      size="sm"
+                      onClick={() => onChange({ toolLimits: mcpPolicies.toolLimits.filter((_, j) => j !== i) })}
+                      disabled={readOnly}
+                    >
+                      <Trash2 className="h-4 w-4" />
+                    </Button>
+                  </div>
+                )
+              })}
             </div>
 
             {/* Info Box */}
@@ -1988,17 +2200,34 @@ function RateLimitEditor({
                 />
               </div>
             </div>
-            <div className="space-y-2">
-              <label className="text-sm font-medium">Burst Limit</label>
-              <Input
-                type="number"
-                value={rateLimitPolicy.burstLimit}
-                onChange={(e) => onChange({ burstLimit: parseInt(e.target.value) || 0 })}
-                disabled={readOnly}
-              />
-              <p className="text-xs text-muted-foreground">
-                Max concurrent requests allowed in a burst
-              </p>
+            <div className="grid grid-cols-2 gap-4">
+              <div className="space-y-2">
+                <label className="text-sm font-medium">Burst Multiplier</label>
+                <Input
+                  type="number"
+                  step="0.1"
+                  min={1}
+                  max={2}
+                  value={rateLimitPolicy.burstMultiplier}
+                  onChange={(e) => onChange({ burstMultiplier: parseFloat(e.target.value) || 1 })}
+                  disabled={readOnly}
+                />
+                <p className="text-xs text-muted-foreground">
+                  Borrow up to this multiple of the per-minute limits; repaid next minute
+                </p>
+              </div>
+              <div className="space-y-2">
+                <label className="text-sm font-medium">Burst Limit</label>
+                <Input
+                  type="number"
+                  value={rateLimitPolicy.burstLimit}
+                  onChange={(e) => onChange({ burstLimit: parseInt(e.target.value) || 0 })}
+                  disabled={readOnly}
+                />
+                <p className="text-xs text-muted-foreground">
+                  Max requests borrowed per minute (0 = no cap)
+                </p>
+              </div>
             </div>
           </CardContent>
         </Card>
@@ -2219,6 +2448,55 @@ function ModelRestrictionsEditor({
               />
             </div>
           </div>
+          <div className="flex items-center justify-between p-4 mt-4 border rounded-lg">
+            <div>
+              <p className="font-medium">Rewrite Deprecated Models</p>
+              <p className="text-sm text-muted-foreground">Move requests for a deprecated model to its replacement</p>
+            </div>
+            <Switch
+              checked={modelRestrictions.rewriteDeprecatedModels}
+              onCheckedChange={(checked) => onChange({ rewriteDeprecatedModels: checked })}
+              disabled={readOnly}
+            />
+          </div>
+          <div className="grid grid-cols-3 gap-4 mt-4">
+            <div className="space-y-2">
+              <label className="text-sm font-medium">Embedding Models</label>
+              <Input
+                value={(modelRestrictions.allowedEmbeddingModels || []).join(', ')}
+                onChange={(e) =>
+                  onChange({
+                    allowedEmbeddingModels: e.target.value
+                      .split(',')
+                      .map((m) => m.trim())
+                      .filter(Boolean),
+                  })
+                }
+                disabled={readOnly}
+                placeholder="Any model"
+              />
+            </div>
+            <div className="space-y-2">
+              <label className="text-sm font-medium">Max Embedding Dimensions</label>
+              <Input
+                type="number"
+                value={modelRestrictions.maxEmbeddingDimensions || 0}
+                onChange={(e) => onChange({ maxEmbeddingDimensions: parseInt(e.target.value) || 0 })}
+                disabled={readOnly}
+                placeholder="0 = no cap"
+              />
+            </div>
+            <div className="space-y-2">
+              <label className="text-sm font-medium">Max Embedding Inputs</label>
+              <Input
+                type="number"
+                value={modelRestrictions.maxEmbeddingInputs || 0}
+                onChange={(e) => onChange({ maxEmbeddingInputs: parseInt(e.target.value) || 0 })}
+                disabled={readOnly}
+                placeholder="0 = no limit"
+              />
+            </div>
+          </div>
         </CardContent>
       </Card>
 
@@ -2511,6 +2789,15 @@ function CachingPolicyEditor({
                 disabled={readOnly || !cachingPolicy.enabled}
               />
             </div>
+
+            <div className="flex items-center justify-between">
+              <label className="text-sm font-medium" title="Clients may skip the cache with the X-ModelGate-Cache header">Allow Bypass</label>
+              <Switch
+                checked={cachingPolicy.allowBypass}
+                onCheckedChange={(allowBypass) => onChange({ allowBypass })}
+                disabled={readOnly || !cachingPolicy.enabled}
+              />
+            </div>
           </div>
         </CardContent>
       </Card>
@@ -2614,6 +2901,20 @@ function RoutingPolicyEditor({
                 disabled={readOnly || !routingPolicy.enabled}
               />
             </div>
+
+            <div className="flex items-center justify-between">
+              <div>
+                <label className="text-sm font-medium">Capability Fallback</label>
+                <p className="text-xs text-muted-foreground">
+                  Reroute requests with images, tools or reasoning the model can't handle to a capable model
+                </p>
+              </div>
+              <Switch
+                checked={routingPolicy.capabilityFallback}
+                onCheckedChange={(capabilityFallback) => onChange({ capabilityFallback })}
+                disabled={readOnly || !routingPolicy.enabled}
+              />
+            </div>
           </div>
 
           {routingPolicy.strategy === 'COST' && (
@@ -2868,6 +3169,133 @@ function ResiliencePolicyEditor({
               disabled={readOnly || !resiliencePolicy.enabled}
             />
           </div>
+
+          {/* Context Window */}
+          <Card className="bg-muted/50">
+            <CardHeader>
+              <CardTitle className="text-sm flex items-center gap-2">
+                <Box className="h-4 w-4" />
+                Context Window Management
+              </CardTitle>
+              <CardDescription className="text-xs">
+                What to do when a request is larger than the model's context window
+              </CardDescription>
+            </CardHeader>
+            <CardContent className="space-y-4">
+              <div className="flex items-center justify-between">
+                <label className="text-sm">Manage Oversized Requests</label>
+                <Switch
+                  checked={resiliencePolicy.contextWindow.enabled}
+                  onCheckedChange={(enabled) =>
+                    onChange({ contextWindow: { ...resiliencePolicy.contextWindow, enabled } })
+                  }
+                  disabled={readOnly || !resiliencePolicy.enabled}
+                />
+              </div>
+              <div className="grid grid-cols-3 gap-4">
+                <div className="space-y-2">
+                  <label className="text-sm">Strategy</label>
+                  <Select
+                    value={resiliencePolicy.contextWindow.strategy}
+                    onValueChange={(strategy) =>
+                      onChange({ contextWindow: { ...resiliencePolicy.contextWindow, strategy } })
+                    }
+                    disabled={readOnly || !resiliencePolicy.enabled || !resiliencePolicy.contextWindow.enabled}
+                  >
+                    <SelectTrigger>
+                      <SelectValue />
+                    </SelectTrigger>
+                    <SelectContent>
+                      <SelectItem value="TRUNCATE">Drop oldest messages</SelectItem>
+                      <SelectItem value="SUMMARIZE">Summarize oldest messages</SelectItem>
+                      <SelectItem value="LARGER_MODEL">Use a larger-context model</SelectItem>
+                    </SelectContent>
+                  </Select>
+                </div>
+                <div className="space-y-2">
+                  <label className="text-sm">Keep Recent Messages</label>
+                  <Input
+                    type="number"
+                    value={resiliencePolicy.contextWindow.keepRecentMessages}
+                    onChange={(e) =>
+                      onChange({
+                        contextWindow: {
+                          ...resiliencePolicy.contextWindow,
+                          keepRecentMessages: parseInt(e.target.value) || 0,
+                        },
+                      })
+                    }
+                    disabled={readOnly || !resiliencePolicy.enabled || !resiliencePolicy.contextWindow.enabled}
+                  />
+                  <p className="text-xs text-muted-foreground">0 = default (4)</p>
+                </div>
+                <div className="space-y-2">
+                  <label className="text-sm">Reserved Output Tokens</label>
+                  <Input
+                    type="number"
+                    value={resiliencePolicy.contextWindow.reserveOutputTokens}
+                    onChange={(e) =>
+                      onChange({
+                        contextWindow: {
+                          ...resiliencePolicy.contextWindow,
+                          reserveOutputTokens: parseInt(e.target.value) || 0,
+                        },
+                      })
+                    }
+                    disabled={readOnly || !resiliencePolicy.enabled || !resiliencePolicy.contextWindow.enabled}
+                  />
+                  <p className="text-xs text-muted-foreground">When max_tokens is unset; 0 = 1024</p>
+                </div>
+              </div>
+              {resiliencePolicy.contextWindow.strategy === 'SUMMARIZE' && (
+                <div className="space-y-2">
+                  <label className="text-sm">Summary Model</label>
+                  <Select
+                    value={resiliencePolicy.contextWindow.summaryModel}
+                    onValueChange={(summaryModel) =>
+                      onChange({ contextWindow: { ...resiliencePolicy.contextWindow, summaryModel } })
+                    }
+                    disabled={readOnly || !resiliencePolicy.enabled || !resiliencePolicy.contextWindow.enabled}
+                  >
+                    <SelectTrigger>
+                      <SelectValue placeholder="Select a low-cost model" />
+                    </SelectTrigger>
+                    <SelectContent>
+                      {availableModels.map((m) => (
+                        <SelectItem key={m.id} value={m.id}>
+                          {m.name}
+                        </SelectItem>
+                      ))}
+                    </SelectContent>
+                  </Select>
+                </div>
+              )}
+              {resiliencePolicy.contextWindow.strategy === 'LARGER_MODEL' && (
+                <div className="space-y-2">
+                  <label className="text-sm">Larger Models</label>
+                  <Input
+                    placeholder="openai/gpt-4.1, google/gemini-2.5-pro"
+                    value={resiliencePolicy.contextWindow.largerModels.join(', ')}
+                    onChange={(e) =>
+                      onChange({
+                        contextWindow: {
+                          ...resiliencePolicy.contextWindow,
+                          largerModels: e.target.value
+                            .split(',')
+                            .map((m) => m.trim())
+                            .filter(Boolean),
+                        },
+                      })
+                    }
+                    disabled={readOnly || !resiliencePolicy.enabled || !resiliencePolicy.contextWindow.enabled}
+                  />
+                  <p className="text-xs text-muted-foreground">
+                    Tried in order. Leave empty to pick the smallest model from the same provider that fits.
+                  </p>
+                </div>
+              )}
+            </CardContent>
+          </Card>
         </CardContent>
       </Card>
     </div>
@@ -2972,6 +3400,44 @@ function BudgetPolicyEditor({
             </CardContent>
           </Card>
 
+          {/* Conversation Ceilings */}
+          <Card className="bg-muted/50">
+            <CardHeader>
+              <CardTitle className="text-sm">Per-Conversation Ceilings</CardTitle>
+              <CardDescription>
+                Refuse further turns once a conversation has used this much (0 = unlimited)
+              </CardDescription>
+            </CardHeader>
+            <CardContent>
+              <div className="grid grid-cols-2 gap-4">
+                <div className="space-y-2">
+                  <label className="text-sm">Max Tokens</label>
+                  <Input
+                    type="number"
+                    step="1000"
+                    value={budgetPolicy.maxTokensPerConversation}
+                    onChange={(e) =>
+                      onChange({ maxTokensPerConversation: parseInt(e.target.value) || 0 })
+                    }
+                    disabled={readOnly || !budgetPolicy.enabled}
+                  />
+                </div>
+                <div className="space-y-2">
+                  <label className="text-sm">Max Cost (USD)</label>
+                  <Input
+                    type="number"
+                    step="0.01"
+                    value={budgetPolicy.maxCostPerConversation}
+                    onChange={(e) =>
+                      onChange({ maxCostPerConversation: parseFloat(e.target.value) || 0 })
+                    }
+                    disabled={readOnly || !budgetPolicy.enabled}
+                  />
+                </div>
+              </div>
+            </CardContent>
+          </Card>
+
           {/* Alert Thresholds */}
           <div className="grid grid-cols-2 gap-6">
             <Card className="bg-muted/50">
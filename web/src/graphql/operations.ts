@@ -78,8 +78,13 @@ export const ROLE_FRAGMENT = gql`
           enabled
           enforceSchema
           detectCodeExecution
+          detectSQLStatements
+          detectShellCommands
+          detectHTMLScripts
           detectSecretLeakage
           detectPIILeakage
+          detectSystemPromptLeakage
+          streamWindowChars
           onViolation
         }
       }
@@ -97,17 +102,34 @@ export const ROLE_FRAGMENT = gql`
         tokensPerMinute
         tokensPerHour
         tokensPerDay
+        burstMultiplier
+        burstLimit
+        burstTokens
       }
       modelRestrictions {
         allowedModels
         allowedProviders
         defaultModel
         maxTokensPerRequest
+        rewriteDeprecatedModels
+        allowedEmbeddingModels
+        maxEmbeddingDimensions
+        maxEmbeddingInputs
       }
       mcpPolicies {
         enabled
         allowToolSearch
         auditToolExecution
+        listDeniedTools
+        maxInvocationsPerMinute
+        maxInvocationsPerDay
+        dailyBudgetUsd
+        monthlyBudgetUsd
+        toolLimits {
+          tool
+          cooldownSeconds
+          costPerCallUsd
+        }
       }
       cachingPolicy {
         enabled
@@ -119,11 +141,13 @@ export const ROLE_FRAGMENT = gql`
         excludedModels
         excludedPatterns
         trackSavings
+        allowBypass
       }
       routingPolicy {
         enabled
         strategy
         allowModelOverride
+        capabilityFallback
       }
       resiliencePolicy {
         enabled
@@ -140,6 +164,20 @@ export const ROLE_FRAGMENT = gql`
         circuitBreakerThreshold
         circuitBreakerTimeout
         requestTimeoutMs
+        contextWindow {
+          enabled
+          strategy
+          reserveOutputTokens
+          keepRecentMessages
+          summaryModel
+          largerModels
+        }
+        hedging {
+          enabled
+          delayMs
+          provider
+          model
+        }
       }
       budgetPolicy {
         enabled
@@ -147,6 +185,8 @@ export const ROLE_FRAGMENT = gql`
         weeklyLimitUSD
         monthlyLimitUSD
         maxCostPerRequest
+        maxTokensPerConversation
+        maxCostPerConversation
         alertThreshold
         criticalThreshold
         alertWebhook
@@ -172,6 +212,19 @@ export const API_KEY_FRAGMENT = gql`
     expiresAt
     isExpired
     revoked
+    allowedCidrs
+    projectId
+    projectName
+    budget {
+      dailySoftLimitUSD
+      dailyHardLimitUSD
+      monthlySoftLimitUSD
+      monthlyHardLimitUSD
+    }
+    budgetStatus {
+      dailySpentUSD
+      monthlySpentUSD
+    }
     role {
       id
       name
@@ -202,6 +255,37 @@ export const LOGIN = gql`
   }
 `
 
+export const CHANGE_PASSWORD = gql`
+  mutation ChangePassword($input: ChangePasswordInput!) {
+    changePassword(input: $input) {
+      token
+      user {
+        id
+        email
+        name
+        role
+      }
+      expiresAt
+    }
+  }
+`
+
+export const BEGIN_TWO_FACTOR_ENROLLMENT = gql`
+  mutation BeginTwoFactorEnrollment($input: TwoFactorCredentialsInput!) {
+    beginTwoFactorEnrollment(input: $input) {
+      secret
+      provisioningUri
+      qrCode
+    }
+  }
+`
+
+export const CONFIRM_TWO_FACTOR_ENROLLMENT = gql`
+  mutation ConfirmTwoFactorEnrollment($input: TwoFactorCredentialsInput!) {
+    confirmTwoFactorEnrollment(input: $input)
+  }
+`
+
 export const LOGOUT = gql`
   mutation Logout {
     logout
@@ -427,6 +511,21 @@ export const PROVIDER_API_KEY_FRAGMENT = gql`
     lastUsedAt
     createdAt
     updatedAt
+    trafficPercent
+    rollbackErrorRate
+    rolledBackAt
+    rollbackReason
+    recentRequests
+    recentErrorRate
+    lastValidatedAt
+    lastValidationOk
+    lastValidationError
+    lastValidationLatencyMs
+    cooldownUntil
+    cooldownReason
+    inputTokens
+    outputTokens
+    costUSD
   }
 `
 
@@ -459,6 +558,25 @@ export const GET_PROVIDERS = gql`
         enableHTTP2
         enableKeepAlive
       }
+      throttles {
+        model
+        maxConcurrent
+        tokensPerMinute
+        maxQueueWaitSec
+      }
+      regions {
+        name
+        priority
+        enabled
+        baseUrl
+        region
+        regionPrefix
+        apiKeyName
+        failedOver
+        failedOverReason
+        failedOverUntil
+      }
+      keyStrategy
       apiKeys {
         ...ProviderAPIKeyFields
       }
@@ -491,6 +609,25 @@ export const UPDATE_PROVIDER = gql`
         enableHTTP2
         enableKeepAlive
       }
+      throttles {
+        model
+        maxConcurrent
+        tokensPerMinute
+        maxQueueWaitSec
+      }
+      regions {
+        name
+        priority
+        enabled
+        baseUrl
+        region
+        regionPrefix
+        apiKeyName
+        failedOver
+        failedOverReason
+        failedOverUntil
+      }
+      keyStrategy
     }
   }
 `
@@ -562,6 +699,23 @@ export const DELETE_PROVIDER_API_KEY = gql`
   }
 `
 
+export const VALIDATE_PROVIDER_API_KEY = gql`
+  mutation ValidateProviderAPIKey($id: ID!) {
+    validateProviderAPIKey(id: $id) {
+      keyId
+      valid
+      method
+      model
+      latencyMs
+      modelCount
+      capabilities
+      errorCode
+      error
+      checkedAt
+    }
+  }
+`
+
 // =============================================================================
 // ROLES
 // =============================================================================
@@ -643,11 +797,25 @@ export const UPDATE_ROLE_POLICY = gql`
         allowedModels
         allowedProviders
         maxTokensPerRequest
+        rewriteDeprecatedModels
+        allowedEmbeddingModels
+        maxEmbeddingDimensions
+        maxEmbeddingInputs
       }
       mcpPolicies {
         enabled
         allowToolSearch
         auditToolExecution
+        listDeniedTools
+        maxInvocationsPerMinute
+        maxInvocationsPerDay
+        dailyBudgetUsd
+        monthlyBudgetUsd
+        toolLimits {
+          tool
+          cooldownSeconds
+          costPerCallUsd
+        }
       }
       cachingPolicy {
         enabled
@@ -659,11 +827,13 @@ export const UPDATE_ROLE_POLICY = gql`
         excludedModels
         excludedPatterns
         trackSavings
+        allowBypass
       }
       routingPolicy {
         enabled
         strategy
         allowModelOverride
+        capabilityFallback
       }
       resiliencePolicy {
         enabled
@@ -680,6 +850,20 @@ export const UPDATE_ROLE_POLICY = gql`
         circuitBreakerThreshold
         circuitBreakerTimeout
         requestTimeoutMs
+        contextWindow {
+          enabled
+          strategy
+          reserveOutputTokens
+          keepRecentMessages
+          summaryModel
+          largerModels
+        }
+        hedging {
+          enabled
+          delayMs
+          provider
+          model
+        }
       }
       budgetPolicy {
         enabled
@@ -687,6 +871,8 @@ export const UPDATE_ROLE_POLICY = gql`
         weeklyLimitUSD
         monthlyLimitUSD
         maxCostPerRequest
+        maxTokensPerConversation
+        maxCostPerConversation
         alertThreshold
         criticalThreshold
         alertWebhook
@@ -700,6 +886,27 @@ export const UPDATE_ROLE_POLICY = gql`
   }
 `
 
+export const DRY_RUN_POLICY = gql`
+  mutation DryRunPolicy($input: PolicyDryRunInput!) {
+    dryRunPolicy(input: $input) {
+      allowed
+      checks {
+        role
+        policy
+        outcome
+        code
+        message
+      }
+      tools {
+        name
+        status
+        reason
+      }
+      removedTools
+    }
+  }
+`
+
 export const DELETE_ROLE = gql`
   mutation DeleteRole($id: ID!) {
     deleteRole(id: $id)
@@ -800,13 +1007,123 @@ export const DELETE_API_KEY = gql`
   }
 `
 
+// =============================================================================
+// PROJECTS
+// =============================================================================
+
+export const PROJECT_FRAGMENT = gql`
+  fragment ProjectFields on Project {
+    id
+    name
+    description
+    budgetPolicy {
+      enabled
+      dailyLimitUSD
+      weeklyLimitUSD
+      monthlyLimitUSD
+      maxCostPerRequest
+      alertThreshold
+      criticalThreshold
+      alertWebhook
+      alertEmails
+      alertSlack
+      onExceeded
+      softLimitEnabled
+      softLimitBuffer
+    }
+    spend {
+      dailyUSD
+      weeklyUSD
+      monthlyUSD
+    }
+    apiKeyCount
+    createdBy
+    createdByEmail
+    createdAt
+    updatedAt
+  }
+`
+
+export const GET_PROJECTS = gql`
+  query GetProjects {
+    projects {
+      ...ProjectFields
+    }
+  }
+  ${PROJECT_FRAGMENT}
+`
+
+export const GET_PROJECT_USAGE = gql`
+  query GetProjectUsage($startDate: DateTime, $endDate: DateTime) {
+    projectUsage(startDate: $startDate, endDate: $endDate) {
+      projectId
+      projectName
+      requests
+      tokens
+      cost
+      percentage
+    }
+  }
+`
+
+export const GET_SPEND_FORECAST = gql`
+  query GetSpendForecast {
+    spendForecast {
+      periodStart
+      periodEnd
+      apiKeys {
+        ...SpendForecastFields
+      }
+      roles {
+        ...SpendForecastFields
+      }
+    }
+  }
+
+  fragment SpendForecastFields on SpendForecast {
+    id
+    name
+    roleId
+    spentUSD
+    dailyBurnUSD
+    projectedUSD
+    budgetUSD
+    overBudget
+    exhaustedAt
+  }
+`
+
+export const CREATE_PROJECT = gql`
+  mutation CreateProject($input: CreateProjectInput!) {
+    createProject(input: $input) {
+      ...ProjectFields
+    }
+  }
+  ${PROJECT_FRAGMENT}
+`
+
+export const UPDATE_PROJECT = gql`
+  mutation UpdateProject($id: ID!, $input: UpdateProjectInput!) {
+    updateProject(id: $id, input: $input) {
+      ...ProjectFields
+    }
+  }
+  ${PROJECT_FRAGMENT}
+`
+
+export const DELETE_PROJECT = gql`
+  mutation DeleteProject($id: ID!) {
+    deleteProject(id: $id)
+  }
+`
+
 // =============================================================================
 // DASHBOARD & ANALYTICS
 // =============================================================================
 
 export const GET_DASHBOARD = gql`
-  query GetDashboard {
-    dashboard {
+  query GetDashboard($projectId: ID) {
+    dashboard(projectId: $projectId) {
       totalRequests
       totalTokens
       totalCostUSD
@@ -888,14 +1205,16 @@ export const GET_REQUEST_LOG_DETAIL = gql`
       prompt
       response
       metadata
+      replayable
+      replayOf
       createdAt
     }
   }
 `
 
 export const GET_COST_ANALYSIS = gql`
-  query GetCostAnalysis($startDate: DateTime, $endDate: DateTime) {
-    costAnalysis(startDate: $startDate, endDate: $endDate) {
+  query GetCostAnalysis($startDate: DateTime, $endDate: DateTime, $projectId: ID) {
+    costAnalysis(startDate: $startDate, endDate: $endDate, projectId: $projectId) {
       totalCost
       periodStart
       periodEnd
@@ -920,8 +1239,8 @@ export const GET_COST_ANALYSIS = gql`
 `
 
 export const GET_PERFORMANCE = gql`
-  query GetPerformance($startDate: DateTime, $endDate: DateTime) {
-    performance(startDate: $startDate, endDate: $endDate) {
+  query GetPerformance($startDate: DateTime, $endDate: DateTime, $projectId: ID) {
+    performance(startDate: $startDate, endDate: $endDate, projectId: $projectId) {
       avgLatencyMs
       p50LatencyMs
       p95LatencyMs
@@ -988,6 +1307,42 @@ export const DELETE_BUDGET_ALERT = gql`
   }
 `
 
+// Request Replay
+export const REPLAY_REQUEST = gql`
+  mutation ReplayRequest($id: ID!, $model: String) {
+    replayRequest(id: $id, model: $model) {
+      recordId
+      requestId
+      identical
+      original {
+        model
+        response
+        success
+        error
+        inputTokens
+        outputTokens
+        costUSD
+        latencyMs
+      }
+      replay {
+        model
+        response
+        success
+        error
+        inputTokens
+        outputTokens
+        costUSD
+        latencyMs
+      }
+      diff {
+        kind
+        left
+        right
+      }
+    }
+  }
+`
+
 // =============================================================================
 // USERS
 // =============================================================================
@@ -1027,6 +1382,35 @@ export const DELETE_USER = gql`
   }
 `
 
+// Usage digest emails for the current user
+export const GET_DIGEST_SUBSCRIPTION = gql`
+  query GetDigestSubscription {
+    digestSubscription {
+      frequency
+      roleId
+      lastSentAt
+      emailEnabled
+    }
+  }
+`
+
+export const UPDATE_DIGEST_SUBSCRIPTION = gql`
+  mutation UpdateDigestSubscription($input: UpdateDigestSubscriptionInput!) {
+    updateDigestSubscription(input: $input) {
+      frequency
+      roleId
+      lastSentAt
+      emailEnabled
+    }
+  }
+`
+
+export const SEND_USAGE_DIGEST = gql`
+  mutation SendUsageDigest {
+    sendUsageDigest
+  }
+`
+
 // =============================================================================
 // AUDIT LOGS
 // =============================================================================
@@ -1062,6 +1446,169 @@ export const GET_AUDIT_LOGS = gql`
   }
 `
 
+export const AUDIT_EXPORT_JOB_FRAGMENT = gql`
+  fragment AuditExportJobFields on AuditExportJob {
+    id
+    status
+    format
+    rowCount
+    sizeBytes
+    objectKey
+    downloadUrl
+    error
+    createdAt
+    completedAt
+    expiresAt
+  }
+`
+
+export const START_AUDIT_LOG_EXPORT = gql`
+  mutation StartAuditLogExport($filter: AuditLogFilter, $format: AuditExportFormat!) {
+    startAuditLogExport(filter: $filter, format: $format) {
+      ...AuditExportJobFields
+    }
+  }
+  ${AUDIT_EXPORT_JOB_FRAGMENT}
+`
+
+export const GET_AUDIT_EXPORT_JOB = gql`
+  query GetAuditExportJob($id: ID!) {
+    auditExportJob(id: $id) {
+      ...AuditExportJobFields
+    }
+  }
+  ${AUDIT_EXPORT_JOB_FRAGMENT}
+`
+
+// =============================================================================
+// FEATURE FLAGS
+// =============================================================================
+
+export const GET_FEATURE_FLAGS = gql`
+  query GetFeatureFlags {
+    featureFlags {
+      key
+      description
+      enabled
+      defaultEnabled
+      roleOverrides {
+        roleId
+        roleName
+        enabled
+      }
+      updatedBy
+      updatedAt
+    }
+  }
+`
+
+export const GET_FEATURE_FLAG_CHANGELOG = gql`
+  query GetFeatureFlagChangelog($filter: FeatureFlagChangelogFilter, $limit: Int) {
+    featureFlagChangelog(filter: $filter, limit: $limit) {
+      id
+      flagKey
+      roleId
+      roleName
+      enabled
+      previousEnabled
+      note
+      actorEmail
+      createdAt
+    }
+  }
+`
+
+export const SET_FEATURE_FLAG = gql`
+  mutation SetFeatureFlag($input: SetFeatureFlagInput!) {
+    setFeatureFlag(input: $input) {
+      key
+      enabled
+      roleOverrides {
+        roleId
+        roleName
+        enabled
+      }
+      updatedBy
+      updatedAt
+    }
+  }
+`
+
+// =============================================================================
+// MODEL PRICING
+// =============================================================================
+
+export const MODEL_PRICE_FRAGMENT = gql`
+  fragment ModelPriceFields on ModelPrice {
+    id
+    provider
+    modelId
+    inputCostPer1M
+    outputCostPer1M
+    source
+    reset
+    effectiveFrom
+    note
+    createdBy
+  }
+`
+
+export const GET_MODEL_PRICES = gql`
+  query GetModelPrices($provider: Provider) {
+    modelPrices(provider: $provider) {
+      ...ModelPriceFields
+    }
+  }
+  ${MODEL_PRICE_FRAGMENT}
+`
+
+export const GET_MODEL_PRICE_HISTORY = gql`
+  query GetModelPriceHistory($provider: Provider!, $modelId: String!) {
+    modelPriceHistory(provider: $provider, modelId: $modelId) {
+      ...ModelPriceFields
+    }
+  }
+  ${MODEL_PRICE_FRAGMENT}
+`
+
+export const SET_MODEL_PRICE_OVERRIDE = gql`
+  mutation SetModelPriceOverride($input: SetModelPriceOverrideInput!) {
+    setModelPriceOverride(input: $input) {
+      ...ModelPriceFields
+    }
+  }
+  ${MODEL_PRICE_FRAGMENT}
+`
+
+export const CLEAR_MODEL_PRICE_OVERRIDE = gql`
+  mutation ClearModelPriceOverride($provider: Provider!, $modelId: String!, $effectiveFrom: DateTime, $note: String) {
+    clearModelPriceOverride(provider: $provider, modelId: $modelId, effectiveFrom: $effectiveFrom, note: $note) {
+      ...ModelPriceFields
+    }
+  }
+  ${MODEL_PRICE_FRAGMENT}
+`
+
+export const REFRESH_MODEL_PRICES = gql`
+  mutation RefreshModelPrices {
+    refreshModelPrices
+  }
+`
+
+// =============================================================================
+// CONFIGURATION
+// =============================================================================
+
+export const RELOAD_CONFIG = gql`
+  mutation ReloadConfig {
+    reloadConfig {
+      changed
+      restartRequired
+      reloadedAt
+    }
+  }
+`
+
 // =============================================================================
 // TOOL POLICY
 // =============================================================================
@@ -57,6 +57,11 @@ export const providerColors: Record<string, string> = {
   MISTRAL: '#ff7000',
   TOGETHER: '#6366f1',
   COHERE: '#39594d',
+  OPENROUTER: '#94a3b8',
+  XAI: '#e5e7eb',
+  DEEPSEEK: '#4d6bfe',
+  HUGGINGFACE: '#ffd21e',
+  FIREWORKS: '#6720ff',
 }
 
 export const providerIcons: Record<string, string> = {
@@ -70,5 +75,10 @@ export const providerIcons: Record<string, string> = {
   MISTRAL: '🌬️',
   TOGETHER: '🤝',
   COHERE: '🔮',
+  OPENROUTER: '🔀',
+  XAI: '✖️',
+  DEEPSEEK: '🐋',
+  HUGGINGFACE: '🤗',
+  FIREWORKS: '🎆',
 }
 
@@ -6,7 +6,12 @@ import { Button } from '@/components/ui/button'
 import { Input } from '@/components/ui/input'
 import { Label } from '@/components/ui/label'
 import { Card, CardContent, CardDescription, CardHeader, CardTitle } from '@/components/ui/card'
-import { LOGIN } from '@/graphql/operations'
+import {
+  LOGIN,
+  CHANGE_PASSWORD,
+  BEGIN_TWO_FACTOR_ENROLLMENT,
+  CONFIRM_TWO_FACTOR_ENROLLMENT,
+} from '@/graphql/operations'
 
 export function LoginPage() {
   const navigate = useNavigate()
@@ -14,6 +19,15 @@ export function LoginPage() {
   const [email, setEmail] = useState('')
   const [password, setPassword] = useState('')
   const [error, setError] = useState('')
+  // Set when the password has expired or is temporary, and must be changed to sign in
+  const [mustChange, setMustChange] = useState(false)
+  const [newPassword, setNewPassword] = useState('')
+  const [confirmPassword, setConfirmPassword] = useState('')
+  // Set when the account has two-factor authentication, or must set it up first
+  const [needsCode, setNeedsCode] = useState(false)
+  const [code, setCode] = useState('')
+  const [enrollment, setEnrollment] = useState<{ secret: string; qrCode: string } | null>(null)
+  const [backupCodes, setBackupCodes] = useState<string[]>([])
 
   const returnTo = searchParams.get('returnTo') || '/dashboard'
 
@@ -23,16 +37,69 @@ export function LoginPage() {
       navigate(returnTo)
     },
     onError: (err) => {
+      if (/must be changed/.test(err.message)) {
+        setMustChange(true)
+      }
+      if (/two-factor code/.test(err.message)) {
+        setNeedsCode(true)
+      }
+      if (/must be set up/.test(err.message)) {
+        beginEnrollment({ variables: { input: { email, password } } })
+      }
       setError(err.message || 'Invalid credentials')
     },
   })
 
+  const [beginEnrollment] = useMutation(BEGIN_TWO_FACTOR_ENROLLMENT, {
+    onCompleted: (data) => {
+      setEnrollment(data.beginTwoFactorEnrollment)
+      setNeedsCode(true)
+      setError('')
+    },
+    onError: (err) => setError(err.message),
+  })
+
+  const [confirmEnrollment, { loading: confirming }] = useMutation(CONFIRM_TWO_FACTOR_ENROLLMENT, {
+    onCompleted: (data) => {
+      setEnrollment(null)
+      setBackupCodes(data.confirmTwoFactorEnrollment)
+      setCode('')
+    },
+    onError: (err) => setError(err.message),
+  })
+
+  const [changePassword, { loading: changing }] = useMutation(CHANGE_PASSWORD, {
+    onCompleted: (data) => {
+      localStorage.setItem('authToken', data.changePassword.token)
+      navigate(returnTo)
+    },
+    onError: (err) => {
+      setError(err.message)
+    },
+  })
+
   const handleSubmit = (e: React.FormEvent) => {
     e.preventDefault()
     setError('')
+    if (enrollment) {
+      confirmEnrollment({ variables: { input: { email, password, code } } })
+      return
+    }
+    if (mustChange) {
+      if (newPassword !== confirmPassword) {
+        setError('The new passwords do not match')
+        return
+      }
+      changePassword({
+        variables: {
+          input: { email, currentPassword: password, newPassword, code: code || undefined },
+        },
+      })
+      return
+    }
     login({
       variables: {
-        input: { email, password },
+        input: { email, password, code: code || undefined },
       },
     })
   }
@@ -91,16 +158,76 @@ export function LoginPage() {
                 />
               </div>
 
+              {enrollment && (
+                <div className="space-y-2 text-sm text-slate-600">
+                  <p>Two-factor authentication is required. Scan this code with an authenticator app, then enter the code it shows.</p>
+                  <img src={enrollment.qrCode} alt="Two-factor QR code" className="mx-auto" />
+                  <p className="font-mono text-xs text-center break-all">{enrollment.secret}</p>
+                </div>
+              )}
+
+              {backupCodes.length > 0 && (
+                <div className="p-3 rounded-lg bg-amber-50 border border-amber-200 text-sm text-amber-800">
+                  <p className="mb-2">Save these backup codes; each signs you in once if you lose your app. Then sign in with your next code.</p>
+                  <p className="font-mono text-xs">{backupCodes.join('  ')}</p>
+                </div>
+              )}
+
+              {needsCode && (
+                <div className="space-y-2">
+                  <Label htmlFor="code">Authentication Code *</Label>
+                  <Input
+                    id="code"
+                    placeholder="123456 or a backup code"
+                    value={code}
+                    onChange={(e) => setCode(e.target.value)}
+                    required
+                    autoComplete="one-time-code"
+                  />
+                </div>
+              )}
+
+              {mustChange && (
+                <>
+                  <div className="space-y-2">
+                    <Label htmlFor="newPassword">New Password *</Label>
+                    <Input
+                      id="newPassword"
+                      type="password"
+                      value={newPassword}
+                      onChange={(e) => setNewPassword(e.target.value)}
+                      required
+                      autoComplete="new-password"
+                    />
+                  </div>
+                  <div className="space-y-2">
+                    <Label htmlFor="confirmPassword">Confirm New Password *</Label>
+                    <Input
+                      id="confirmPassword"
+                      type="password"
+                      value={confirmPassword}
+                      onChange={(e) => setConfirmPassword(e.target.value)}
+                      required
+                      autoComplete="new-password"
+                    />
+                  </div>
+                </>
+              )}
+
               <Button
                 type="submit"
                 className="w-full bg-gradient-to-r from-emerald-500 to-cyan-500 hover:from-emerald-600 hover:to-cyan-600"
-                disabled={loading}
+                disabled={loading || changing || confirming}
               >
-                {loading ? (
+                {loading || changing || confirming ? (
                   <>
                     <Loader2 className="mr-2 h-4 w-4 animate-spin" />
                     Signing in...
                   </>
+                ) : enrollment ? (
+                  'Turn On Two-Factor'
+                ) : mustChange ? (
+                  'Change Password and Sign In'
                 ) : (
                   'Sign In'
                 )}
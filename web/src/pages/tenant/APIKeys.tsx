@@ -150,6 +150,16 @@ export function APIKeysPage() {
                       ) : (
                         <Badge className="bg-green-500/20 text-green-400">Active</Badge>
                       )}
+                      {key.budget && key.budgetStatus && (
+                        <div className="text-xs text-muted-foreground mt-1">
+                          {key.budget.dailyHardLimitUSD > 0 && (
+                            <div>Today: ${key.budgetStatus.dailySpentUSD.toFixed(2)} / ${key.budget.dailyHardLimitUSD.toFixed(2)}</div>
+                          )}
+                          {key.budget.monthlyHardLimitUSD > 0 && (
+                            <div>Month: ${key.budgetStatus.monthlySpentUSD.toFixed(2)} / ${key.budget.monthlyHardLimitUSD.toFixed(2)}</div>
+                          )}
+                        </div>
+                      )}
                     </TableCell>
                     <TableCell>
                       {key.createdByEmail ? (
@@ -253,6 +263,7 @@ function CreateAPIKeyDialog({
   const [groupId, setGroupId] = useState('')
   const [hasExpiry, setHasExpiry] = useState(false)
   const [expiryDate, setExpiryDate] = useState('')
+  const [allowedCidrs, setAllowedCidrs] = useState('')
 
   const handleSubmit = () => {
     const input: any = {
@@ -271,6 +282,12 @@ function CreateAPIKeyDialog({
       input.expiresAt = new Date(expiryDate).toISOString()
     }
 
+    // Restrict the key to these client IPs / CIDRs
+    const cidrs = allowedCidrs.split(/[\s,]+/).filter(Boolean)
+    if (cidrs.length > 0) {
+      input.allowedCidrs = cidrs
+    }
+
     onSubmit(input)
 
     // Reset form
@@ -279,6 +296,7 @@ function CreateAPIKeyDialog({
     setGroupId('')
     setHasExpiry(false)
     setExpiryDate('')
+    setAllowedCidrs('')
     onOpenChange(false)
   }
 
@@ -373,6 +391,17 @@ function CreateAPIKeyDialog({
               By default, keys never expire. Set an expiry date if needed.
             </p>
           </div>
+          <div className="space-y-2">
+            <label className="text-sm font-medium">Allowed IPs</label>
+            <Input
+              placeholder="e.g., 10.0.0.0/8, 203.0.113.7"
+              value={allowedCidrs}
+              onChange={(e) => setAllowedCidrs(e.target.value)}
+            />
+            <p className="text-xs text-muted-foreground">
+              Comma-separated IPs or CIDRs. Leave empty to allow any address.
+            </p>
+          </div>
         </div>
         <DialogFooter>
           <Button variant="outline" onClick={() => onOpenChange(false)}>
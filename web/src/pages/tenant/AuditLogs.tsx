@@ -1,5 +1,5 @@
-import { useState } from 'react';
-import { useQuery } from '@apollo/client';
+import { useEffect, useState } from 'react';
+import { useMutation, useQuery } from '@apollo/client';
 import { Card } from '@/components/ui/card';
 import { Button } from '@/components/ui/button';
 import { Input } from '@/components/ui/input';
@@ -39,9 +39,10 @@ import {
   FileText,
   AlertTriangle,
   CheckCircle,
-  XCircle
+  XCircle,
+  Download
 } from 'lucide-react';
-import { GET_AUDIT_LOGS } from '@/graphql/operations';
+import { GET_AUDIT_LOGS, START_AUDIT_LOG_EXPORT, GET_AUDIT_EXPORT_JOB } from '@/graphql/operations';
 
 interface AuditLog {
   id: string;
@@ -80,6 +81,10 @@ const resourceTypeLabels: Record<string, string> = {
   PROVIDER: 'Provider',
   TENANT: 'Tenant',
   SESSION: 'Session',
+  FEATURE_FLAG: 'Feature Flag',
+  CONFIG: 'Configuration',
+  MODEL_PRICE: 'Model Price',
+  AUDIT_LOG: 'Audit Log',
 };
 
 export default function AuditLogs() {
@@ -92,12 +97,41 @@ export default function AuditLogs() {
     search: '',
   });
 
+  const [exportJobId, setExportJobId] = useState<string | null>(null);
+
+  const queryFilter = {
+    action: filters.action !== 'all' ? filters.action : undefined,
+    resourceType: filters.resourceType !== 'all' ? filters.resourceType : undefined,
+  };
+
+  const [startExport, { loading: startingExport, error: exportError }] = useMutation(START_AUDIT_LOG_EXPORT);
+  const { data: exportData, stopPolling } = useQuery(GET_AUDIT_EXPORT_JOB, {
+    variables: { id: exportJobId },
+    skip: !exportJobId,
+    pollInterval: 2000,
+    fetchPolicy: 'network-only',
+  });
+  const exportJob = exportData?.auditExportJob;
+
+  // Download the file once the export finishes
+  useEffect(() => {
+    if (!exportJob || exportJob.status === 'PENDING' || exportJob.status === 'RUNNING') return;
+    stopPolling();
+    if (exportJob.downloadUrl) {
+      window.location.assign(exportJob.downloadUrl);
+    }
+  }, [exportJob, stopPolling]);
+
+  const handleExport = async (format: 'CSV' | 'JSONL') => {
+    const result = await startExport({ variables: { filter: queryFilter, format } });
+    setExportJobId(result.data?.startAuditLogExport?.id ?? null);
+  };
+
+  const exportRunning = !!exportJobId && (!exportJob || exportJob.status === 'PENDING' || exportJob.status === 'RUNNING');
+
   const { data, loading, error, refetch } = useQuery(GET_AUDIT_LOGS, {
     variables: {
-      filter: {
-        action: filters.action !== 'all' ? filters.action : undefined,
-        resourceType: filters.resourceType !== 'all' ? filters.resourceType : undefined,
-      },
+      filter: queryFilter,
       limit: pageSize,
       offset: page * pageSize,
     },
@@ -167,12 +201,34 @@ export default function AuditLogs() {
             </p>
           </div>
         </div>
-        <Button variant="outline" onClick={() => refetch()}>
-          <RefreshCw className="h-4 w-4 mr-2" />
-          Refresh
-        </Button>
+        <div className="flex items-center gap-2">
+          <Button variant="outline" disabled={startingExport || exportRunning} onClick={() => handleExport('CSV')}>
+            <Download className="h-4 w-4 mr-2" />
+            {exportRunning ? 'Exporting...' : 'Export CSV'}
+          </Button>
+          <Button variant="outline" disabled={startingExport || exportRunning} onClick={() => handleExport('JSONL')}>
+            <Download className="h-4 w-4 mr-2" />
+            Export JSONL
+          </Button>
+          <Button variant="outline" onClick={() => refetch()}>
+            <RefreshCw className="h-4 w-4 mr-2" />
+            Refresh
+          </Button>
+        </div>
       </div>
 
+      {(exportError || exportJob?.status === 'FAILED' || exportJob?.objectKey) && (
+        <Card className="p-4 text-sm">
+          {exportError && <span className="text-red-400">Export failed: {exportError.message}</span>}
+          {exportJob?.status === 'FAILED' && <span className="text-red-400">Export failed: {exportJob.error}</span>}
+          {exportJob?.objectKey && (
+            <span className="text-muted-foreground">
+              Exported {exportJob.rowCount} events to <code>{exportJob.objectKey}</code>
+            </span>
+          )}
+        </Card>
+      )}
+
       {/* Filters */}
       <Card className="p-4">
         <div className="flex flex-wrap gap-4">
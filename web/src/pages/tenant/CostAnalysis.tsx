@@ -2,15 +2,74 @@ import { useState, useMemo } from 'react';
 import { useQuery } from '@apollo/client';
 import { Card, CardContent, CardDescription, CardHeader, CardTitle } from '@/components/ui/card';
 import { Button } from '@/components/ui/button';
+import { Badge } from '@/components/ui/badge';
+import { Table, TableBody, TableCell, TableHead, TableHeader, TableRow } from '@/components/ui/table';
 import { Select, SelectContent, SelectItem, SelectTrigger, SelectValue } from '@/components/ui/select';
 import { LineChart, Line, XAxis, YAxis, CartesianGrid, Tooltip, ResponsiveContainer, BarChart, Bar, PieChart, Pie, Cell } from 'recharts';
-import { GET_COST_ANALYSIS } from '@/graphql/operations';
+import { ProjectSelect, projectIdVariable } from '@/components/ProjectSelect';
+import { GET_COST_ANALYSIS, GET_SPEND_FORECAST } from '@/graphql/operations';
 import { Loader2 } from 'lucide-react';
 
 const COLORS = ['#6366f1', '#22c55e', '#f59e0b', '#ef4444', '#8b5cf6', '#06b6d4'];
 
+interface SpendForecast {
+  id: string;
+  name: string;
+  roleId: string | null;
+  spentUSD: number;
+  dailyBurnUSD: number;
+  projectedUSD: number;
+  budgetUSD: number;
+  overBudget: boolean;
+  exhaustedAt: string | null;
+}
+
+// SpendForecastTable lists month-end projections against each budget
+function SpendForecastTable({ forecasts, label }: { forecasts: SpendForecast[]; label: string }) {
+  if (forecasts.length === 0) {
+    return <div className="py-4 text-center text-sm text-muted-foreground">No {label.toLowerCase()} with spend this month</div>;
+  }
+  return (
+    <Table>
+      <TableHeader>
+        <TableRow>
+          <TableHead>{label}</TableHead>
+          <TableHead className="text-right">Spent</TableHead>
+          <TableHead className="text-right">Per day</TableHead>
+          <TableHead className="text-right">Projected</TableHead>
+          <TableHead className="text-right">Budget</TableHead>
+          <TableHead>Status</TableHead>
+        </TableRow>
+      </TableHeader>
+      <TableBody>
+        {forecasts.map((fc) => (
+          <TableRow key={fc.id}>
+            <TableCell className="font-medium">{fc.name || fc.id}</TableCell>
+            <TableCell className="text-right">${fc.spentUSD.toFixed(2)}</TableCell>
+            <TableCell className="text-right">${fc.dailyBurnUSD.toFixed(2)}</TableCell>
+            <TableCell className="text-right">${fc.projectedUSD.toFixed(2)}</TableCell>
+            <TableCell className="text-right">{fc.budgetUSD > 0 ? `$${fc.budgetUSD.toFixed(2)}` : '—'}</TableCell>
+            <TableCell>
+              {fc.budgetUSD <= 0 ? (
+                <Badge variant="secondary">No budget</Badge>
+              ) : fc.overBudget ? (
+                <Badge variant="destructive">
+                  {fc.exhaustedAt ? `Runs out ${new Date(fc.exhaustedAt).toLocaleDateString()}` : 'Over budget'}
+                </Badge>
+              ) : (
+                <Badge variant="success">{((fc.projectedUSD / fc.budgetUSD) * 100).toFixed(0)}% of budget</Badge>
+              )}
+            </TableCell>
+          </TableRow>
+        ))}
+      </TableBody>
+    </Table>
+  );
+}
+
 export default function CostAnalysis() {
   const [period, setPeriod] = useState('month');
+  const [project, setProject] = useState('all');
 
   // Calculate date range based on period filter
   const dateRange = useMemo(() => {
@@ -42,6 +101,7 @@ export default function CostAnalysis() {
     variables: {
       startDate: dateRange.startDate,
       endDate: dateRange.endDate,
+      projectId: projectIdVariable(project),
     },
     fetchPolicy: 'network-only',
     pollInterval: 30000, // Refresh every 30 seconds
@@ -49,6 +109,13 @@ export default function CostAnalysis() {
 
   const costAnalysis = data?.costAnalysis;
 
+  // Month-end projections always cover the current month, whatever the period
+  const { data: forecastData } = useQuery(GET_SPEND_FORECAST, {
+    fetchPolicy: 'network-only',
+    pollInterval: 300000,
+  });
+  const forecast = forecastData?.spendForecast;
+
   // Calculate metrics from real data
   const dailyCosts = costAnalysis?.dailyCosts || [];
   const costByModel = costAnalysis?.costByModel || [];
@@ -102,6 +169,7 @@ export default function CostAnalysis() {
             </svg>
             Refresh
           </Button>
+          <ProjectSelect value={project} onChange={setProject} />
           <Select value={period} onValueChange={setPeriod}>
             <SelectTrigger className="w-[140px]">
               <SelectValue placeholder="Period" />
@@ -312,6 +380,20 @@ export default function CostAnalysis() {
         </Card>
       )}
 
+      {/* Spend Forecast */}
+      {forecast && (
+        <Card>
+          <CardHeader>
+            <CardTitle>Spend Forecast</CardTitle>
+            <CardDescription>Projected month-end spend at the last 7 days' burn rate, against each monthly budget</CardDescription>
+          </CardHeader>
+          <CardContent className="space-y-6">
+            <SpendForecastTable forecasts={forecast.roles} label="Roles" />
+            <SpendForecastTable forecasts={forecast.apiKeys} label="API keys" />
+          </CardContent>
+        </Card>
+      )}
+
       {/* Budget Alerts */}
       <Card>
         <CardHeader className="flex flex-row items-center justify-between">
@@ -1,3 +1,4 @@
+import { useState } from 'react'
 import { useQuery } from '@apollo/client'
 import { useParams } from 'react-router-dom'
 import {
@@ -18,6 +19,7 @@ import {
 import { Activity, DollarSign, Zap, Clock, TrendingUp, TrendingDown } from 'lucide-react'
 import { Card, CardContent, CardDescription, CardHeader, CardTitle } from '@/components/ui/card'
 import { Badge } from '@/components/ui/badge'
+import { ProjectSelect, projectIdVariable } from '@/components/ProjectSelect'
 import { GET_DASHBOARD } from '@/graphql/operations'
 import { formatNumber, formatCurrency, providerColors } from '@/lib/utils'
 
@@ -25,7 +27,9 @@ const COLORS = ['#8b5cf6', '#06b6d4', '#10b981', '#f59e0b', '#ef4444', '#ec4899'
 
 export function DashboardPage() {
   const { tenant } = useParams()
+  const [project, setProject] = useState('all')
   const { data, loading, error } = useQuery(GET_DASHBOARD, {
+    variables: { projectId: projectIdVariable(project) },
     pollInterval: 30000, // Refresh every 30 seconds
   })
 
@@ -81,11 +85,14 @@ export function DashboardPage() {
   return (
     <div className="space-y-8">
       {/* Header */}
-      <div>
-        <h1 className="text-3xl font-bold">Dashboard</h1>
-        <p className="text-muted-foreground">
-          Overview of your LLM usage and performance
-        </p>
+      <div className="flex items-center justify-between">
+        <div>
+          <h1 className="text-3xl font-bold">Dashboard</h1>
+          <p className="text-muted-foreground">
+            Overview of your LLM usage and performance
+          </p>
+        </div>
+        <ProjectSelect value={project} onChange={setProject} />
       </div>
 
       {/* Stat Cards */}
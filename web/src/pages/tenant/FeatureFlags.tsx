@@ -0,0 +1,262 @@
+import { useState } from 'react';
+import { useQuery, useMutation } from '@apollo/client';
+import { Card, CardContent, CardDescription, CardHeader, CardTitle } from '@/components/ui/card';
+import { Button } from '@/components/ui/button';
+import { Input } from '@/components/ui/input';
+import { Switch } from '@/components/ui/switch';
+import { Badge } from '@/components/ui/badge';
+import { Table, TableBody, TableCell, TableHead, TableHeader, TableRow } from '@/components/ui/table';
+import { Select, SelectContent, SelectItem, SelectTrigger, SelectValue } from '@/components/ui/select';
+import { RefreshCw, X } from 'lucide-react';
+import {
+  GET_FEATURE_FLAGS,
+  GET_FEATURE_FLAG_CHANGELOG,
+  SET_FEATURE_FLAG,
+  GET_ROLES,
+} from '@/graphql/operations';
+
+interface RoleOverride {
+  roleId: string;
+  roleName: string | null;
+  enabled: boolean;
+}
+
+interface FeatureFlag {
+  key: string;
+  description: string;
+  enabled: boolean;
+  defaultEnabled: boolean;
+  roleOverrides: RoleOverride[];
+  updatedBy: string | null;
+  updatedAt: string | null;
+}
+
+interface FeatureFlagChange {
+  id: string;
+  flagKey: string;
+  roleId: string | null;
+  roleName: string | null;
+  enabled: boolean | null;
+  previousEnabled: boolean | null;
+  note: string | null;
+  actorEmail: string | null;
+  createdAt: string;
+}
+
+const stateLabel = (enabled: boolean | null) => {
+  if (enabled === null) return 'unset';
+  return enabled ? 'on' : 'off';
+};
+
+export default function FeatureFlags() {
+  const { data, loading, error, refetch } = useQuery<{ featureFlags: FeatureFlag[] }>(GET_FEATURE_FLAGS, {
+    fetchPolicy: 'network-only',
+  });
+  const { data: rolesData } = useQuery(GET_ROLES);
+  const [changelogFlag, setChangelogFlag] = useState('all');
+  const { data: changelogData, refetch: refetchChangelog } = useQuery<{ featureFlagChangelog: FeatureFlagChange[] }>(
+    GET_FEATURE_FLAG_CHANGELOG,
+    {
+      variables: {
+        filter: { flagKey: changelogFlag !== 'all' ? changelogFlag : undefined },
+        limit: 100,
+      },
+      fetchPolicy: 'network-only',
+    }
+  );
+  const [setFeatureFlag] = useMutation(SET_FEATURE_FLAG);
+
+  const [note, setNote] = useState('');
+  const [overrideRole, setOverrideRole] = useState<Record<string, string>>({});
+
+  const flags = data?.featureFlags || [];
+  const roles: { id: string; name: string }[] = rolesData?.roles || [];
+  const changelog = changelogData?.featureFlagChangelog || [];
+
+  const applyChange = async (key: string, enabled: boolean | null, roleId?: string) => {
+    try {
+      await setFeatureFlag({
+        variables: {
+          input: { key, roleId, enabled, note: note || undefined },
+        },
+      });
+      setNote('');
+      refetch();
+      refetchChangelog();
+    } catch (err) {
+      console.error('Failed to update feature flag:', err);
+      alert('Failed to update feature flag: ' + (err as Error).message);
+    }
+  };
+
+  if (loading) {
+    return (
+      <div className="flex items-center justify-center h-64">
+        <div className="text-muted-foreground">Loading feature flags...</div>
+      </div>
+    );
+  }
+
+  if (error) {
+    return (
+      <div className="flex items-center justify-center h-64">
+        <div className="text-red-500">Error loading feature flags: {error.message}</div>
+      </div>
+    );
+  }
+
+  return (
+    <div className="space-y-6">
+      <div>
+        <h1 className="text-3xl font-bold tracking-tight">Feature Flags</h1>
+        <p className="text-muted-foreground">
+          Roll out new gateway behaviors gradually, per tenant or per role
+        </p>
+      </div>
+
+      <Card>
+        <CardHeader>
+          <CardTitle>Flags</CardTitle>
+          <CardDescription>
+            Role overrides take precedence over the tenant-wide value. Changes apply within 30 seconds on every instance.
+          </CardDescription>
+        </CardHeader>
+        <CardContent className="space-y-6">
+          <div className="space-y-2">
+            <label className="text-sm font-medium">Change note</label>
+            <Input
+              placeholder="Why are you changing this flag? (shown in the changelog)"
+              value={note}
+              onChange={(e) => setNote(e.target.value)}
+            />
+          </div>
+
+          {flags.map((flag) => (
+            <div key={flag.key} className="rounded-lg border p-4 space-y-4">
+              <div className="flex items-start justify-between gap-4">
+                <div className="space-y-1">
+                  <div className="flex items-center gap-2">
+                    <code className="text-sm font-semibold">{flag.key}</code>
+                    {flag.enabled !== flag.defaultEnabled && <Badge variant="secondary">Changed from default</Badge>}
+                  </div>
+                  <p className="text-sm text-muted-foreground">{flag.description}</p>
+                  {flag.updatedAt && (
+                    <p className="text-xs text-muted-foreground">
+                      Last changed {new Date(flag.updatedAt).toLocaleString()}
+                      {flag.updatedBy ? ` by ${flag.updatedBy}` : ''}
+                    </p>
+                  )}
+                </div>
+                <Switch checked={flag.enabled} onCheckedChange={(checked) => applyChange(flag.key, checked)} />
+              </div>
+
+              <div className="space-y-2">
+                {flag.roleOverrides.map((o) => (
+                  <div key={o.roleId} className="flex items-center justify-between text-sm">
+                    <span>{o.roleName || o.roleId}</span>
+                    <div className="flex items-center gap-2">
+                      <Switch checked={o.enabled} onCheckedChange={(checked) => applyChange(flag.key, checked, o.roleId)} />
+                      <Button variant="ghost" size="sm" onClick={() => applyChange(flag.key, null, o.roleId)}>
+                        <X className="h-4 w-4" />
+                      </Button>
+                    </div>
+                  </div>
+                ))}
+                <div className="flex items-center gap-2">
+                  <Select
+                    value={overrideRole[flag.key] || ''}
+                    onValueChange={(value) => setOverrideRole({ ...overrideRole, [flag.key]: value })}
+                  >
+                    <SelectTrigger className="w-[220px]">
+                      <SelectValue placeholder="Override for role..." />
+                    </SelectTrigger>
+                    <SelectContent>
+                      {roles
+                        .filter((r) => !flag.roleOverrides.some((o) => o.roleId === r.id))
+                        .map((r) => (
+                          <SelectItem key={r.id} value={r.id}>
+                            {r.name}
+                          </SelectItem>
+                        ))}
+                    </SelectContent>
+                  </Select>
+                  <Button
+                    variant="outline"
+                    size="sm"
+                    disabled={!overrideRole[flag.key]}
+                    onClick={() => applyChange(flag.key, !flag.enabled, overrideRole[flag.key])}
+                  >
+                    {flag.enabled ? 'Disable for role' : 'Enable for role'}
+                  </Button>
+                </div>
+              </div>
+            </div>
+          ))}
+        </CardContent>
+      </Card>
+
+      <Card>
+        <CardHeader>
+          <div className="flex items-center justify-between">
+            <div>
+              <CardTitle>Changelog</CardTitle>
+              <CardDescription>When flags were switched, to correlate behavior changes with incidents</CardDescription>
+            </div>
+            <div className="flex items-center gap-2">
+              <Select value={changelogFlag} onValueChange={setChangelogFlag}>
+                <SelectTrigger className="w-[200px]">
+                  <SelectValue />
+                </SelectTrigger>
+                <SelectContent>
+                  <SelectItem value="all">All flags</SelectItem>
+                  {flags.map((f) => (
+                    <SelectItem key={f.key} value={f.key}>
+                      {f.key}
+                    </SelectItem>
+                  ))}
+                </SelectContent>
+              </Select>
+              <Button variant="outline" size="sm" onClick={() => refetchChangelog()}>
+                <RefreshCw className="h-4 w-4" />
+              </Button>
+            </div>
+          </div>
+        </CardHeader>
+        <CardContent>
+          {changelog.length === 0 ? (
+            <p className="text-sm text-muted-foreground">No flag changes recorded yet.</p>
+          ) : (
+            <Table>
+              <TableHeader>
+                <TableRow>
+                  <TableHead>Time</TableHead>
+                  <TableHead>Flag</TableHead>
+                  <TableHead>Scope</TableHead>
+                  <TableHead>Change</TableHead>
+                  <TableHead>By</TableHead>
+                  <TableHead>Note</TableHead>
+                </TableRow>
+              </TableHeader>
+              <TableBody>
+                {changelog.map((c) => (
+                  <TableRow key={c.id}>
+                    <TableCell className="whitespace-nowrap">{new Date(c.createdAt).toLocaleString()}</TableCell>
+                    <TableCell>
+                      <code className="text-xs">{c.flagKey}</code>
+                    </TableCell>
+                    <TableCell>{c.roleId ? `Role: ${c.roleName || c.roleId}` : 'Tenant'}</TableCell>
+                    <TableCell>
+                      {stateLabel(c.previousEnabled)} → {stateLabel(c.enabled)}
+                    </TableCell>
+                    <TableCell>{c.actorEmail || '-'}</TableCell>
+                    <TableCell className="text-muted-foreground">{c.note || ''}</TableCell>
+                  </TableRow>
+                ))}
+              </TableBody>
+            </Table>
+          )}
+        </CardContent>
+      </Card>
+    </div>
+  );
+}
@@ -3,7 +3,7 @@ import { useQuery, useMutation } from '@apollo/client'
 import { gql } from '@apollo/client'
 import { 
   Plus, Server, RefreshCw, Plug, PlugZap, Trash2, Settings,
-  CheckCircle, XCircle, Clock, AlertTriangle, Wrench
+  CheckCircle, XCircle, Clock, AlertTriangle, Wrench, ShieldQuestion
 } from 'lucide-react'
 
 import { Button } from '@/components/ui/button'
@@ -142,6 +142,62 @@ const SEARCH_TOOLS = gql`
   }
 `
 
+const GET_MCP_TOOL_APPROVALS = gql`
+  query GetMCPToolApprovals($status: String) {
+    mcpToolApprovals(status: $status) {
+      id
+      roleId
+      roleName
+      serverName
+      toolName
+      status
+      requestCount
+      firstRequestedAt
+      lastRequestedAt
+      visibility
+      decidedByEmail
+      decidedAt
+      decisionReason
+    }
+  }
+`
+
+const APPROVE_MCP_TOOL = gql`
+  mutation ApproveMCPTool($input: ApproveMCPToolInput!) {
+    approveMCPTool(input: $input) {
+      id
+      status
+      visibility
+    }
+  }
+`
+
+const DENY_MCP_TOOL = gql`
+  mutation DenyMCPTool($input: DenyMCPToolInput!) {
+    denyMCPTool(input: $input) {
+      id
+      status
+      decisionReason
+    }
+  }
+`
+
+interface MCPToolApproval {
+  id: string
+  roleId: string
+  roleName: string
+  serverName: string
+  toolName: string
+  status: string
+  requestCount: number
+  firstRequestedAt: string
+  lastRequestedAt: string
+  visibility: string | null
+  decidedByEmail: string | null
+  decidedAt: string | null
+  decisionReason: string | null
+}
+
 interface MCPServer {
   id: string
   name: string
@@ -192,6 +248,9 @@ export default function MCPServersPage() {
   const [selectedServer, setSelectedServer] = useState<string | null>(null)
   const [searchQuery, setSearchQuery] = useState('')
   const [activeTab, setActiveTab] = useState('servers')
+  const [deciding, setDeciding] = useState<{ approval: MCPToolApproval; action: 'approve' | 'deny' } | null>(null)
+  const [decisionReason, setDecisionReason] = useState('')
+  const [grantVisibility, setGrantVisibility] = useState('ALLOW')
 
   // Form state
   const [formData, setFormData] = useState({
@@ -211,6 +270,9 @@ export default function MCPServersPage() {
     variables: { serverId: selectedServer },
     skip: !selectedServer && activeTab !== 'tools',
   })
+  const { data: approvalsData, loading: approvalsLoading, refetch: refetchApprovals } = useQuery(GET_MCP_TOOL_APPROVALS, {
+    variables: { status: 'pending' },
+  })
   const { data: searchData, loading: searchLoading } = useQuery(SEARCH_TOOLS, {
     variables: { input: { query: searchQuery, maxResults: 20 } },
     skip: !searchQuery || searchQuery.length < 2,
@@ -289,6 +351,37 @@ export default function MCPServersPage() {
     },
   })
 
+  const onDecided = (description: string) => {
+    toast({ title: 'Success', description })
+    setDeciding(null)
+    setDecisionReason('')
+    refetchApprovals()
+  }
+
+  const [approveTool] = useMutation(APPROVE_MCP_TOOL, {
+    onCompleted: () => onDecided('Tool approved for the role'),
+    onError: (error) => {
+      toast({ title: 'Error', description: error.message, variant: 'destructive' })
+    },
+  })
+
+  const [denyTool] = useMutation(DENY_MCP_TOOL, {
+    onCompleted: () => onDecided('Tool denied for the role'),
+    onError: (error) => {
+      toast({ title: 'Error', description: error.message, variant: 'destructive' })
+    },
+  })
+
+  const handleDecide = () => {
+    if (!deciding) return
+    const reason = decisionReason.trim()
+    if (deciding.action === 'approve') {
+      approveTool({ variables: { input: { approvalId: deciding.approval.id, visibility: grantVisibility, reason: reason || null } } })
+    } else {
+      denyTool({ variables: { input: { approvalId: deciding.approval.id, reason } } })
+    }
+  }
+
   const [syncingServerId, setSyncingServerId] = useState<string | null>(null)
   const [connectingServerId, setConnectingServerId] = useState<string | null>(null)
 
@@ -369,6 +462,7 @@ export default function MCPServersPage() {
 
   const servers: MCPServer[] = serversData?.mcpServers || []
   const tools: MCPTool[] = toolsData?.mcpTools || []
+  const approvals: MCPToolApproval[] = approvalsData?.mcpToolApprovals || []
   const searchResults = searchData?.searchTools?.tools || []
 
   return (
@@ -653,6 +747,10 @@ export default function MCPServersPage() {
             <RefreshCw className="h-4 w-4 mr-2" />
             Search
           </TabsTrigger>
+          <TabsTrigger value="approvals">
+            <ShieldQuestion className="h-4 w-4 mr-2" />
+            Approvals ({approvals.length})
+          </TabsTrigger>
         </TabsList>
 
         {/* Servers Tab */}
@@ -955,7 +1053,109 @@ export default function MCPServersPage() {
             </Card>
           )}
         </TabsContent>
+
+        {/* Approvals Tab */}
+        <TabsContent value="approvals" className="space-y-4">
+          {approvalsLoading ? (
+            <div className="text-center py-8 text-muted-foreground">Loading approvals...</div>
+          ) : approvals.length === 0 ? (
+            <Card>
+              <CardContent className="py-12 text-center">
+                <ShieldQuestion className="h-12 w-12 mx-auto text-muted-foreground mb-4" />
+                <h3 className="text-lg font-medium mb-2">No Pending Approvals</h3>
+                <p className="text-muted-foreground">
+                  Tools a role calls without permission are queued here for review
+                </p>
+              </CardContent>
+            </Card>
+          ) : (
+            <Card>
+              <Table>
+                <TableHeader>
+                  <TableRow>
+                    <TableHead>Tool</TableHead>
+                    <TableHead>Role</TableHead>
+                    <TableHead className="text-right">Calls</TableHead>
+                    <TableHead>First Requested</TableHead>
+                    <TableHead className="text-right">Decision</TableHead>
+                  </TableRow>
+                </TableHeader>
+                <TableBody>
+                  {approvals.map((approval) => (
+                    <TableRow key={approval.id}>
+                      <TableCell>
+                        <div>
+                          <code className="text-sm font-mono">{approval.toolName}</code>
+                          <p className="text-xs text-muted-foreground">{approval.serverName}</p>
+                        </div>
+                      </TableCell>
+                      <TableCell>
+                        <Badge variant="outline">{approval.roleName}</Badge>
+                      </TableCell>
+                      <TableCell className="text-right">{approval.requestCount}</TableCell>
+                      <TableCell>{new Date(approval.firstRequestedAt).toLocaleString()}</TableCell>
+                      <TableCell className="text-right space-x-2">
+                        <Button size="sm" onClick={() => setDeciding({ approval, action: 'approve' })}>
+                          <CheckCircle className="h-4 w-4 mr-1" />
+                          Approve
+                        </Button>
+                        <Button size="sm" variant="outline" onClick={() => setDeciding({ approval, action: 'deny' })}>
+                          <XCircle className="h-4 w-4 mr-1" />
+                          Deny
+                        </Button>
+                      </TableCell>
+                    </TableRow>
+                  ))}
+                </TableBody>
+              </Table>
+            </Card>
+          )}
+        </TabsContent>
       </Tabs>
+
+      <Dialog open={deciding !== null} onOpenChange={(open) => !open && setDeciding(null)}>
+        <DialogContent>
+          <DialogHeader>
+            <DialogTitle>
+              {deciding?.action === 'approve' ? 'Approve' : 'Deny'} {deciding?.approval.toolName}
+            </DialogTitle>
+            <DialogDescription>
+              For role {deciding?.approval.roleName}. The role's callers see the reason.
+            </DialogDescription>
+          </DialogHeader>
+          <div className="space-y-4">
+            {deciding?.action === 'approve' && (
+              <div className="space-y-2">
+                <Label>Visibility</Label>
+                <Select value={grantVisibility} onValueChange={setGrantVisibility}>
+                  <SelectTrigger>
+                    <SelectValue />
+                  </SelectTrigger>
+                  <SelectContent>
+                    <SelectItem value="ALLOW">Allow (listed and searchable)</SelectItem>
+                    <SelectItem value="SEARCH">Search only</SelectItem>
+                  </SelectContent>
+                </Select>
+              </div>
+            )}
+            <div className="space-y-2">
+              <Label>Reason{deciding?.action === 'approve' ? ' (optional)' : ''}</Label>
+              <Textarea value={decisionReason} onChange={(e) => setDecisionReason(e.target.value)} />
+            </div>
+          </div>
+          <DialogFooter>
+            <Button variant="outline" onClick={() => setDeciding(null)}>
+              Cancel
+            </Button>
+            <Button
+              onClick={handleDecide}
+              disabled={deciding?.action === 'deny' && !decisionReason.trim()}
+            >
+              {deciding?.action === 'approve' ? 'Approve' : 'Deny'}
+            </Button>
+          </DialogFooter>
+        </DialogContent>
+      </Dialog>
     </div>
   )
 }
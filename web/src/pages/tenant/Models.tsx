@@ -45,6 +45,11 @@ const PROVIDER_NAMES: Record<string, string> = {
   MISTRAL: 'Mistral AI',
   TOGETHER: 'Together AI',
   COHERE: 'Cohere',
+  OPENROUTER: 'OpenRouter',
+  XAI: 'xAI',
+  DEEPSEEK: 'DeepSeek',
+  HUGGINGFACE: 'Hugging Face',
+  FIREWORKS: 'Fireworks AI',
 }
 
 export function ModelsPage() {
@@ -0,0 +1,313 @@
+import { useState } from 'react';
+import { useQuery, useMutation } from '@apollo/client';
+import { Card, CardContent, CardDescription, CardHeader, CardTitle } from '@/components/ui/card';
+import { Button } from '@/components/ui/button';
+import { Input } from '@/components/ui/input';
+import { Badge } from '@/components/ui/badge';
+import { Table, TableBody, TableCell, TableHead, TableHeader, TableRow } from '@/components/ui/table';
+import { Select, SelectContent, SelectItem, SelectTrigger, SelectValue } from '@/components/ui/select';
+import { RefreshCw, History, X } from 'lucide-react';
+import {
+  GET_MODEL_PRICES,
+  GET_MODEL_PRICE_HISTORY,
+  SET_MODEL_PRICE_OVERRIDE,
+  CLEAR_MODEL_PRICE_OVERRIDE,
+  REFRESH_MODEL_PRICES,
+} from '@/graphql/operations';
+
+interface ModelPrice {
+  id: string | null;
+  provider: string;
+  modelId: string;
+  inputCostPer1M: number;
+  outputCostPer1M: number;
+  source: 'CATALOG' | 'PROVIDER' | 'CONFIG' | 'OVERRIDE';
+  reset: boolean;
+  effectiveFrom: string | null;
+  note: string | null;
+  createdBy: string | null;
+}
+
+const sourceLabels: Record<ModelPrice['source'], string> = {
+  CATALOG: 'Catalog',
+  PROVIDER: 'Provider',
+  CONFIG: 'Config',
+  OVERRIDE: 'Override',
+};
+
+const formatPrice = (value: number) => `$${value.toFixed(4)}`;
+
+export default function Pricing() {
+  const [provider, setProvider] = useState('all');
+  const { data, loading, error, refetch } = useQuery<{ modelPrices: ModelPrice[] }>(GET_MODEL_PRICES, {
+    variables: { provider: provider !== 'all' ? provider : undefined },
+    fetchPolicy: 'network-only',
+  });
+  const [selected, setSelected] = useState<ModelPrice | null>(null);
+  const { data: historyData, refetch: refetchHistory } = useQuery<{ modelPriceHistory: ModelPrice[] }>(
+    GET_MODEL_PRICE_HISTORY,
+    {
+      variables: { provider: selected?.provider, modelId: selected?.modelId },
+      skip: !selected,
+      fetchPolicy: 'network-only',
+    }
+  );
+  const [setOverride] = useMutation(SET_MODEL_PRICE_OVERRIDE);
+  const [clearOverride] = useMutation(CLEAR_MODEL_PRICE_OVERRIDE);
+  const [refreshPrices, { loading: refreshing }] = useMutation(REFRESH_MODEL_PRICES);
+
+  const [inputCost, setInputCost] = useState('');
+  const [outputCost, setOutputCost] = useState('');
+  const [effectiveFrom, setEffectiveFrom] = useState('');
+  const [note, setNote] = useState('');
+
+  const prices = data?.modelPrices || [];
+  const providers = Array.from(new Set(prices.map((p) => p.provider))).sort();
+  const history = historyData?.modelPriceHistory || [];
+
+  const selectModel = (price: ModelPrice) => {
+    setSelected(price);
+    setInputCost(String(price.inputCostPer1M));
+    setOutputCost(String(price.outputCostPer1M));
+    setEffectiveFrom('');
+    setNote('');
+  };
+
+  const afterChange = () => {
+    refetch();
+    refetchHistory();
+  };
+
+  const handleSetOverride = async () => {
+    if (!selected) return;
+    try {
+      await setOverride({
+        variables: {
+          input: {
+            provider: selected.provider,
+            modelId: selected.modelId,
+            inputCostPer1M: parseFloat(inputCost),
+            outputCostPer1M: parseFloat(outputCost),
+            effectiveFrom: effectiveFrom ? new Date(effectiveFrom).toISOString() : undefined,
+            note: note || undefined,
+          },
+        },
+      });
+      setNote('');
+      afterChange();
+    } catch (err) {
+      console.error('Failed to set price override:', err);
+      alert('Failed to set price override: ' + (err as Error).message);
+    }
+  };
+
+  const handleClearOverride = async () => {
+    if (!selected) return;
+    try {
+      await clearOverride({
+        variables: {
+          provider: selected.provider,
+          modelId: selected.modelId,
+          effectiveFrom: effectiveFrom ? new Date(effectiveFrom).toISOString() : undefined,
+          note: note || undefined,
+        },
+      });
+      setNote('');
+      afterChange();
+    } catch (err) {
+      console.error('Failed to clear price override:', err);
+      alert('Failed to clear price override: ' + (err as Error).message);
+    }
+  };
+
+  const handleRefresh = async () => {
+    try {
+      const result = await refreshPrices();
+      alert(`Recorded ${result.data?.refreshModelPrices ?? 0} price change(s)`);
+      afterChange();
+    } catch (err) {
+      console.error('Failed to refresh prices:', err);
+      alert('Failed to refresh prices: ' + (err as Error).message);
+    }
+  };
+
+  if (loading && !data) {
+    return (
+      <div className="flex items-center justify-center h-64">
+        <div className="text-muted-foreground">Loading model prices...</div>
+      </div>
+    );
+  }
+
+  if (error) {
+    return (
+      <div className="flex items-center justify-center h-64">
+        <div className="text-red-500">Error loading model prices: {error.message}</div>
+      </div>
+    );
+  }
+
+  return (
+    <div className="space-y-6">
+      <div className="flex items-center justify-between">
+        <div>
+          <h1 className="text-3xl font-bold tracking-tight">Model Pricing</h1>
+          <p className="text-muted-foreground">
+            Prices used to compute request costs. Recorded usage keeps the price it was billed at.
+          </p>
+        </div>
+        <Button variant="outline" onClick={handleRefresh} disabled={refreshing}>
+          <RefreshCw className={`h-4 w-4 mr-2 ${refreshing ? 'animate-spin' : ''}`} />
+          Refresh Prices
+        </Button>
+      </div>
+
+      <Card>
+        <CardHeader>
+          <div className="flex items-center justify-between">
+            <div>
+              <CardTitle>Current Prices</CardTitle>
+              <CardDescription>USD per 1M tokens. Overrides take precedence over provider and catalog prices.</CardDescription>
+            </div>
+            <Select value={provider} onValueChange={setProvider}>
+              <SelectTrigger className="w-[200px]">
+                <SelectValue />
+              </SelectTrigger>
+              <SelectContent>
+                <SelectItem value="all">All providers</SelectItem>
+                {providers.map((p) => (
+                  <SelectItem key={p} value={p}>
+                    {p.toLowerCase()}
+                  </SelectItem>
+                ))}
+              </SelectContent>
+            </Select>
+          </div>
+        </CardHeader>
+        <CardContent>
+          <Table>
+            <TableHeader>
+              <TableRow>
+                <TableHead>Provider</TableHead>
+                <TableHead>Model</TableHead>
+                <TableHead className="text-right">Input</TableHead>
+                <TableHead className="text-right">Output</TableHead>
+                <TableHead>Source</TableHead>
+                <TableHead>Effective</TableHead>
+                <TableHead></TableHead>
+              </TableRow>
+            </TableHeader>
+            <TableBody>
+              {prices.map((p) => (
+                <TableRow key={`${p.provider}/${p.modelId}`}>
+                  <TableCell>{p.provider.toLowerCase()}</TableCell>
+                  <TableCell>
+                    <code className="text-xs">{p.modelId}</code>
+                  </TableCell>
+                  <TableCell className="text-right">{formatPrice(p.inputCostPer1M)}</TableCell>
+                  <TableCell className="text-right">{formatPrice(p.outputCostPer1M)}</TableCell>
+                  <TableCell>
+                    <Badge variant={p.source === 'OVERRIDE' ? 'default' : 'secondary'}>{sourceLabels[p.source]}</Badge>
+                  </TableCell>
+                  <TableCell className="whitespace-nowrap">
+                    {p.effectiveFrom ? new Date(p.effectiveFrom).toLocaleString() : 'Bundled'}
+                  </TableCell>
+                  <TableCell>
+                    <Button variant="ghost" size="sm" onClick={() => selectModel(p)}>
+                      <History className="h-4 w-4" />
+                    </Button>
+                  </TableCell>
+                </TableRow>
+              ))}
+            </TableBody>
+          </Table>
+        </CardContent>
+      </Card>
+
+      {selected && (
+        <Card>
+          <CardHeader>
+            <div className="flex items-center justify-between">
+              <div>
+                <CardTitle>
+                  {selected.provider.toLowerCase()}/{selected.modelId}
+                </CardTitle>
+                <CardDescription>Set an override effective now or at a future date, and review past prices</CardDescription>
+              </div>
+              <Button variant="ghost" size="sm" onClick={() => setSelected(null)}>
+                <X className="h-4 w-4" />
+              </Button>
+            </div>
+          </CardHeader>
+          <CardContent className="space-y-6">
+            <div className="grid grid-cols-2 gap-4 md:grid-cols-4">
+              <div className="space-y-2">
+                <label className="text-sm font-medium">Input ($/1M)</label>
+                <Input type="number" min="0" step="0.0001" value={inputCost} onChange={(e) => setInputCost(e.target.value)} />
+              </div>
+              <div className="space-y-2">
+                <label className="text-sm font-medium">Output ($/1M)</label>
+                <Input type="number" min="0" step="0.0001" value={outputCost} onChange={(e) => setOutputCost(e.target.value)} />
+              </div>
+              <div className="space-y-2">
+                <label className="text-sm font-medium">Effective from</label>
+                <Input type="datetime-local" value={effectiveFrom} onChange={(e) => setEffectiveFrom(e.target.value)} />
+              </div>
+              <div className="space-y-2">
+                <label className="text-sm font-medium">Note</label>
+                <Input placeholder="e.g. negotiated rate" value={note} onChange={(e) => setNote(e.target.value)} />
+              </div>
+            </div>
+            <div className="flex gap-2">
+              <Button onClick={handleSetOverride} disabled={inputCost === '' || outputCost === ''}>
+                Set Override
+              </Button>
+              <Button variant="outline" onClick={handleClearOverride}>
+                Clear Override
+              </Button>
+            </div>
+
+            {history.length === 0 ? (
+              <p className="text-sm text-muted-foreground">No recorded prices yet; the bundled catalog price applies.</p>
+            ) : (
+              <Table>
+                <TableHeader>
+                  <TableRow>
+                    <TableHead>Effective</TableHead>
+                    <TableHead>Source</TableHead>
+                    <TableHead className="text-right">Input</TableHead>
+                    <TableHead className="text-right">Output</TableHead>
+                    <TableHead>By</TableHead>
+                    <TableHead>Note</TableHead>
+                  </TableRow>
+                </TableHeader>
+                <TableBody>
+                  {history.map((h) => (
+                    <TableRow key={h.id ?? h.effectiveFrom}>
+                      <TableCell className="whitespace-nowrap">
+                        {h.effectiveFrom ? new Date(h.effectiveFrom).toLocaleString() : '-'}
+                      </TableCell>
+                      <TableCell>{sourceLabels[h.source]}</TableCell>
+                      {h.reset ? (
+                        <TableCell colSpan={2} className="text-right text-muted-foreground">
+                          Override cleared
+                        </TableCell>
+                      ) : (
+                        <>
+                          <TableCell className="text-right">{formatPrice(h.inputCostPer1M)}</TableCell>
+                          <TableCell className="text-right">{formatPrice(h.outputCostPer1M)}</TableCell>
+                        </>
+                      )}
+                      <TableCell>{h.createdBy || '-'}</TableCell>
+                      <TableCell className="text-muted-foreground">{h.note || ''}</TableCell>
+                    </TableRow>
+                  ))}
+                </TableBody>
+              </Table>
+            )}
+          </CardContent>
+        </Card>
+      )}
+    </div>
+  );
+}
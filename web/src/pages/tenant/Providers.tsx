@@ -22,6 +22,11 @@ const PROVIDER_INFO: Record<string, { name: string; description: string; default
   MISTRAL: { name: 'Mistral', description: 'Mistral Large, Medium, Small', defaultBaseUrl: 'https://api.mistral.ai/v1' },
   TOGETHER: { name: 'Together AI', description: 'Open-source models at scale', defaultBaseUrl: 'https://api.together.xyz/v1' },
   COHERE: { name: 'Cohere', description: 'Command models for enterprise', defaultBaseUrl: 'https://api.cohere.com/v2' },
+  OPENROUTER: { name: 'OpenRouter', description: 'Hundreds of models behind one API key', defaultBaseUrl: 'https://openrouter.ai/api/v1' },
+  XAI: { name: 'xAI', description: 'Grok 4, Grok 3 and Grok Code models', defaultBaseUrl: 'https://api.x.ai/v1' },
+  DEEPSEEK: { name: 'DeepSeek', description: 'DeepSeek Chat and Reasoner with context caching', defaultBaseUrl: 'https://api.deepseek.com' },
+  HUGGINGFACE: { name: 'Hugging Face', description: 'Serverless Inference API and dedicated Inference Endpoints', defaultBaseUrl: 'https://router.huggingface.co/v1' },
+  FIREWORKS: { name: 'Fireworks AI', description: 'Fast open-source models with grammar-constrained output', defaultBaseUrl: 'https://api.fireworks.ai/inference/v1' },
 }
 
 export function ProvidersPage() {
@@ -1,5 +1,5 @@
 import { useState, useMemo } from 'react';
-import { useQuery } from '@apollo/client';
+import { useQuery, useMutation } from '@apollo/client';
 import { Card, CardContent, CardDescription, CardHeader, CardTitle } from '@/components/ui/card';
 import { Button } from '@/components/ui/button';
 import { Input } from '@/components/ui/input';
@@ -7,8 +7,8 @@ import { Badge } from '@/components/ui/badge';
 import { Table, TableBody, TableCell, TableHead, TableHeader, TableRow } from '@/components/ui/table';
 import { Select, SelectContent, SelectItem, SelectTrigger, SelectValue } from '@/components/ui/select';
 import { Dialog, DialogContent, DialogDescription, DialogHeader, DialogTitle } from '@/components/ui/dialog';
-import { GET_REQUEST_LOGS, GET_API_KEYS, GET_REQUEST_LOG_DETAIL } from '@/graphql/operations';
-import { Loader2 } from 'lucide-react';
+import { GET_REQUEST_LOGS, GET_API_KEYS, GET_REQUEST_LOG_DETAIL, REPLAY_REQUEST } from '@/graphql/operations';
+import { Loader2, RotateCcw } from 'lucide-react';
 
 interface RequestLog {
   id: string;
@@ -26,6 +26,110 @@ interface RequestLog {
   createdAt: string;
 }
 
+interface ReplaySide {
+  model: string;
+  response: string | null;
+  success: boolean;
+  error: string | null;
+  inputTokens: number;
+  outputTokens: number;
+  costUSD: number;
+  latencyMs: number;
+}
+
+interface ReplayResult {
+  recordId: string;
+  requestId: string;
+  identical: boolean;
+  original: ReplaySide;
+  replay: ReplaySide;
+  diff: { kind: 'EQUAL' | 'CHANGED' | 'REMOVED' | 'ADDED'; left: string | null; right: string | null }[];
+}
+
+const diffRowClasses: Record<string, { left: string; right: string }> = {
+  EQUAL: { left: '', right: '' },
+  CHANGED: { left: 'bg-amber-50 dark:bg-amber-950/40', right: 'bg-amber-50 dark:bg-amber-950/40' },
+  REMOVED: { left: 'bg-red-50 dark:bg-red-950/40', right: '' },
+  ADDED: { left: '', right: 'bg-green-50 dark:bg-green-950/40' },
+};
+
+// Re-runs a logged request, optionally on another model, and shows the two
+// responses side by side. Replays need the usage:replay permission.
+function ReplayPanel({ log }: { log: RequestLog }) {
+  const [model, setModel] = useState('');
+  const [replayRequest, { data, loading, error }] = useMutation<{ replayRequest: ReplayResult }>(REPLAY_REQUEST);
+  const result = data?.replayRequest;
+
+  const handleReplay = () => {
+    replayRequest({ variables: { id: log.id, model: model.trim() || undefined } }).catch(() => {}); // Shown from error
+  };
+
+  const sideSummary = (label: string, side: ReplaySide) => (
+    <div className="bg-muted rounded-lg p-3 space-y-1">
+      <div className="flex items-center justify-between">
+        <p className="text-xs text-muted-foreground">{label}</p>
+        <Badge variant={side.success ? 'default' : 'destructive'}>{side.success ? 'success' : 'error'}</Badge>
+      </div>
+      <p className="font-medium">{side.model}</p>
+      <p className="text-xs text-muted-foreground">
+        {side.inputTokens} in / {side.outputTokens} out · ${side.costUSD.toFixed(4)} · {side.latencyMs}ms
+      </p>
+      {side.error && <p className="font-mono text-xs text-red-600 dark:text-red-400">{side.error}</p>}
+    </div>
+  );
+
+  return (
+    <div className="border-t pt-4 space-y-3">
+      <h4 className="font-medium flex items-center gap-2">
+        <RotateCcw className="w-5 h-5" />
+        Replay
+      </h4>
+      <div className="flex gap-2">
+        <Input
+          placeholder={`Model (default ${log.model})`}
+          value={model}
+          onChange={(e) => setModel(e.target.value)}
+        />
+        <Button onClick={handleReplay} disabled={loading}>
+          {loading ? <Loader2 className="h-4 w-4 mr-2 animate-spin" /> : <RotateCcw className="h-4 w-4 mr-2" />}
+          Replay
+        </Button>
+      </div>
+      {error && (
+        <div className="rounded-lg bg-destructive/10 p-3 text-sm text-destructive">
+          Replay failed: {error.message}
+        </div>
+      )}
+      {result && (
+        <div className="space-y-3">
+          <div className="grid grid-cols-2 gap-3">
+            {sideSummary('Original', result.original)}
+            {sideSummary('Replay', result.replay)}
+          </div>
+          <div className="flex items-center justify-between text-sm">
+            <span>{result.identical ? 'The responses are identical' : 'The responses differ'}</span>
+            <span className="font-mono text-xs text-muted-foreground">{result.requestId}</span>
+          </div>
+          {!result.identical && result.diff.length > 0 && (
+            <div className="rounded-lg border overflow-hidden">
+              {result.diff.map((row, i) => (
+                <div key={i} className="grid grid-cols-2 font-mono text-xs">
+                  <div className={`p-1 px-2 whitespace-pre-wrap break-words border-r ${diffRowClasses[row.kind].left}`}>
+                    {row.left ?? ''}
+                  </div>
+                  <div className={`p-1 px-2 whitespace-pre-wrap break-words ${diffRowClasses[row.kind].right}`}>
+                    {row.right ?? ''}
+                  </div>
+                </div>
+              ))}
+            </div>
+          )}
+        </div>
+      )}
+    </div>
+  );
+}
+
 export default function RequestLogs() {
   const [searchQuery, setSearchQuery] = useState('');
   const [periodFilter, setPeriodFilter] = useState('24h');
@@ -489,6 +593,8 @@ export default function RequestLogs() {
                   </div>
                 </div>
               )}
+
+              <ReplayPanel key={selectedLog.id} log={selectedLog} />
             </div>
           )}
         </DialogContent>
@@ -1,12 +1,13 @@
 import { useState } from 'react'
 import { useQuery, useMutation } from '@apollo/client'
-import { Plus, Shield, Edit2, Trash2, Lock, ChevronRight, ChevronDown, ChevronsRight, ChevronsLeft, Database, Route, RefreshCw, DollarSign } from 'lucide-react'
+import { Plus, Shield, Edit2, Trash2, Lock, ChevronRight, ChevronDown, ChevronsRight, ChevronsLeft, Database, Route, RefreshCw, DollarSign, Play } from 'lucide-react'
 import { Button } from '@/components/ui/button'
 import { Badge } from '@/components/ui/badge'
 import { Card, CardContent, CardDescription, CardHeader, CardTitle } from '@/components/ui/card'
 import { Tabs, TabsContent, TabsList, TabsTrigger } from '@/components/ui/tabs'
 import { Input } from '@/components/ui/input'
 import { Switch } from '@/components/ui/switch'
+import { Textarea } from '@/components/ui/textarea'
 import {
   Dialog,
   DialogContent,
@@ -30,7 +31,7 @@ import {
   TableHeader,
   TableRow,
 } from '@/components/ui/table'
-import { GET_ROLES, GET_GROUPS, CREATE_ROLE, UPDATE_ROLE_POLICY, DELETE_ROLE, CREATE_GROUP, DELETE_GROUP, GET_AVAILABLE_MODELS } from '@/graphql/operations'
+import { GET_ROLES, GET_GROUPS, CREATE_ROLE, UPDATE_ROLE_POLICY, DELETE_ROLE, CREATE_GROUP, DELETE_GROUP, GET_AVAILABLE_MODELS, DRY_RUN_POLICY } from '@/graphql/operations'
 import { PolicyEditorAdvanced } from '@/components/policies/PolicyEditorAdvanced'
 
 interface Role {
@@ -62,6 +63,18 @@ interface Role {
         maxPromptLength: number
         maxMessageCount: number
       }
+      outputValidation?: {
+        enabled: boolean
+        detectCodeExecution: boolean
+        detectSQLStatements: boolean
+        detectShellCommands: boolean
+        detectHTMLScripts: boolean
+        detectSecretLeakage: boolean
+        detectPIILeakage: boolean
+        detectSystemPromptLeakage: boolean
+        streamWindowChars: number
+        onViolation: string
+      }
     }
     toolPolicies?: {
       allowToolCalling: boolean
@@ -77,12 +90,19 @@ interface Role {
       tokensPerMinute: number
       tokensPerHour: number
       tokensPerDay: number
+      burstMultiplier?: number
+      burstLimit?: number
+      burstTokens?: number
     }
     modelRestrictions?: {
       allowedModels: string[]
       allowedProviders: string[]
       defaultModel: string
       maxTokensPerRequest: number
+      rewriteDeprecatedModels?: boolean
+      allowedEmbeddingModels?: string[]
+      maxEmbeddingDimensions?: number
+      maxEmbeddingInputs?: number
     }
     cachingPolicy?: {
       enabled: boolean
@@ -94,11 +114,13 @@ interface Role {
       excludedModels: string[]
       excludedPatterns: string[]
       trackSavings: boolean
+      allowBypass: boolean
     }
     routingPolicy?: {
       enabled: boolean
       strategy: string
       allowModelOverride: boolean
+      capabilityFallback: boolean
     }
     resiliencePolicy?: {
       enabled: boolean
@@ -117,6 +139,14 @@ interface Role {
       circuitBreakerThreshold: number
       circuitBreakerTimeout: number
       requestTimeoutMs: number
+      contextWindow?: {
+        enabled: boolean
+        strategy: string
+        reserveOutputTokens: number
+        keepRecentMessages: number
+        summaryModel: string
+        largerModels: string[]
+      }
     }
     budgetPolicy?: {
       enabled: boolean
@@ -124,6 +154,8 @@ interface Role {
       weeklyLimitUSD: number
       monthlyLimitUSD: number
       maxCostPerRequest: number
+      maxTokensPerConversation: number
+      maxCostPerConversation: number
       alertThreshold: number
       criticalThreshold: number
       alertWebhook: string
@@ -148,6 +180,7 @@ export function RolesPage() {
   const [showCreateRole, setShowCreateRole] = useState(false)
   const [showCreateGroup, setShowCreateGroup] = useState(false)
   const [editingRole, setEditingRole] = useState<Role | null>(null)
+  const [dryRunRole, setDryRunRole] = useState<Role | null>(null)
   
   const { data: rolesData, loading: rolesLoading, refetch: refetchRoles } = useQuery(GET_ROLES)
   const { data: groupsData, loading: groupsLoading, refetch: refetchGroups } = useQuery(GET_GROUPS)
@@ -285,6 +318,14 @@ export function RolesPage() {
                       </TableCell>
                       <TableCell className="text-right">
                         <div className="flex justify-end gap-2">
+                          <Button
+                            variant="ghost"
+                            size="sm"
+                            title="Dry run"
+                            onClick={() => setDryRunRole(role)}
+                          >
+                            <Play className="h-4 w-4" />
+                          </Button>
                           <Button
                             variant="ghost"
                             size="sm"
@@ -419,6 +460,16 @@ export function RolesPage() {
           availableModels={availableModels}
         />
       )}
+
+      {/* Policy Dry Run Dialog */}
+      {dryRunRole && (
+        <DryRunDialog
+          role={dryRunRole}
+          open={!!dryRunRole}
+          onOpenChange={(open) => !open && setDryRunRole(null)}
+          availableModels={availableModels}
+        />
+      )}
     </div>
   )
 }
@@ -697,11 +748,16 @@ function EditPolicyDialog({
         onDetection: role.policy?.promptPolicies?.contentFiltering?.onDetection || 'BLOCK',
       },
       outputValidation: {
-        enabled: true,
-        detectCodeExecution: true,
-        detectSecretLeakage: true,
-        detectPIILeakage: true,
-        onViolation: 'REDACT',
+        enabled: role.policy?.promptPolicies?.outputValidation?.enabled ?? true,
+        detectCodeExecution: role.policy?.promptPolicies?.outputValidation?.detectCodeExecution ?? true,
+        detectSQLStatements: role.policy?.promptPolicies?.outputValidation?.detectSQLStatements ?? false,
+        detectShellCommands: role.policy?.promptPolicies?.outputValidation?.detectShellCommands ?? false,
+        detectHTMLScripts: role.policy?.promptPolicies?.outputValidation?.detectHTMLScripts ?? false,
+        detectSecretLeakage: role.policy?.promptPolicies?.outputValidation?.detectSecretLeakage ?? true,
+        detectPIILeakage: role.policy?.promptPolicies?.outputValidation?.detectPIILeakage ?? true,
+        detectSystemPromptLeakage: role.policy?.promptPolicies?.outputValidation?.detectSystemPromptLeakage ?? false,
+        streamWindowChars: role.policy?.promptPolicies?.outputValidation?.streamWindowChars ?? 0,
+        onViolation: role.policy?.promptPolicies?.outputValidation?.onViolation || 'REDACT',
       },
     },
     toolPolicies: {
@@ -720,13 +776,19 @@ function EditPolicyDialog({
       tokensPerDay: role.policy?.rateLimitPolicy?.tokensPerDay || 0,
       costPerDayUSD: 100,
       costPerMonthUSD: 1000,
-      burstLimit: 10,
+      burstMultiplier: role.policy?.rateLimitPolicy?.burstMultiplier || 1,
+      burstLimit: role.policy?.rateLimitPolicy?.burstLimit ?? 10,
+      burstTokens: role.policy?.rateLimitPolicy?.burstTokens || 0,
     },
     modelRestrictions: {
       allowedModels: role.policy?.modelRestrictions?.allowedModels || [],
       allowedProviders: role.policy?.modelRestrictions?.allowedProviders || [],
       defaultModel: '',
       maxTokensPerRequest: role.policy?.modelRestrictions?.maxTokensPerRequest || 0,
+      rewriteDeprecatedModels: role.policy?.modelRestrictions?.rewriteDeprecatedModels || false,
+      allowedEmbeddingModels: role.policy?.modelRestrictions?.allowedEmbeddingModels || [],
+      maxEmbeddingDimensions: role.policy?.modelRestrictions?.maxEmbeddingDimensions || 0,
+      maxEmbeddingInputs: role.policy?.modelRestrictions?.maxEmbeddingInputs || 0,
     },
     cachingPolicy: {
       enabled: role.policy?.cachingPolicy?.enabled ?? false,
@@ -738,11 +800,13 @@ function EditPolicyDialog({
       excludedModels: role.policy?.cachingPolicy?.excludedModels || [],
       excludedPatterns: role.policy?.cachingPolicy?.excludedPatterns || [],
       trackSavings: role.policy?.cachingPolicy?.trackSavings ?? true,
+      allowBypass: role.policy?.cachingPolicy?.allowBypass ?? false,
     },
     routingPolicy: {
       enabled: role.policy?.routingPolicy?.enabled ?? false,
       strategy: role.policy?.routingPolicy?.strategy || 'COST',
       allowModelOverride: role.policy?.routingPolicy?.allowModelOverride ?? true,
+      capabilityFallback: role.policy?.routingPolicy?.capabilityFallback ?? false,
     },
     resiliencePolicy: {
       enabled: role.policy?.resiliencePolicy?.enabled ?? false,
@@ -761,6 +825,14 @@ function EditPolicyDialog({
       circuitBreakerThreshold: role.policy?.resiliencePolicy?.circuitBreakerThreshold ?? 5,
       circuitBreakerTimeout: role.policy?.resiliencePolicy?.circuitBreakerTimeout ?? 60,
       requestTimeoutMs: role.policy?.resiliencePolicy?.requestTimeoutMs ?? 30000,
+      contextWindow: {
+        enabled: role.policy?.resiliencePolicy?.contextWindow?.enabled ?? false,
+        strategy: role.policy?.resiliencePolicy?.contextWindow?.strategy || 'TRUNCATE',
+        reserveOutputTokens: role.policy?.resiliencePolicy?.contextWindow?.reserveOutputTokens ?? 0,
+        keepRecentMessages: role.policy?.resiliencePolicy?.contextWindow?.keepRecentMessages ?? 0,
+        summaryModel: role.policy?.resiliencePolicy?.contextWindow?.summaryModel || '',
+        largerModels: role.policy?.resiliencePolicy?.contextWindow?.largerModels || [],
+      },
     },
     budgetPolicy: {
       enabled: role.policy?.budgetPolicy?.enabled ?? false,
@@ -768,6 +840,8 @@ function EditPolicyDialog({
       weeklyLimitUSD: role.policy?.budgetPolicy?.weeklyLimitUSD ?? 0,
       monthlyLimitUSD: role.policy?.budgetPolicy?.monthlyLimitUSD ?? 0,
       maxCostPerRequest: role.policy?.budgetPolicy?.maxCostPerRequest ?? 0,
+      maxTokensPerConversation: role.policy?.budgetPolicy?.maxTokensPerConversation ?? 0,
+      maxCostPerConversation: role.policy?.budgetPolicy?.maxCostPerConversation ?? 0,
       alertThreshold: role.policy?.budgetPolicy?.alertThreshold ?? 0.8,
       criticalThreshold: role.policy?.budgetPolicy?.criticalThreshold ?? 0.95,
       alertWebhook: role.policy?.budgetPolicy?.alertWebhook ?? '',
@@ -781,6 +855,16 @@ function EditPolicyDialog({
       enabled: (role.policy as any)?.mcpPolicies?.enabled || false,
       allowToolSearch: (role.policy as any)?.mcpPolicies?.allowToolSearch || false,
       auditToolExecution: (role.policy as any)?.mcpPolicies?.auditToolExecution || false,
+      listDeniedTools: (role.policy as any)?.mcpPolicies?.listDeniedTools || false,
+      maxInvocationsPerMinute: (role.policy as any)?.mcpPolicies?.maxInvocationsPerMinute ?? 0,
+      maxInvocationsPerDay: (role.policy as any)?.mcpPolicies?.maxInvocationsPerDay ?? 0,
+      dailyBudgetUsd: (role.policy as any)?.mcpPolicies?.dailyBudgetUsd ?? 0,
+      monthlyBudgetUsd: (role.policy as any)?.mcpPolicies?.monthlyBudgetUsd ?? 0,
+      toolLimits: ((role.policy as any)?.mcpPolicies?.toolLimits || []).map((l: any) => ({
+        tool: l.tool,
+        cooldownSeconds: l.cooldownSeconds,
+        costPerCallUsd: l.costPerCallUsd,
+      })),
     },
   }
 
@@ -831,17 +915,25 @@ function EditPolicyDialog({
         tokensPerMinute: currentPolicy.rateLimitPolicy.tokensPerMinute,
         tokensPerHour: currentPolicy.rateLimitPolicy.tokensPerHour,
         tokensPerDay: currentPolicy.rateLimitPolicy.tokensPerDay,
+        burstMultiplier: currentPolicy.rateLimitPolicy.burstMultiplier,
+        burstLimit: currentPolicy.rateLimitPolicy.burstLimit,
+        burstTokens: currentPolicy.rateLimitPolicy.burstTokens,
       },
       modelRestrictions: {
         allowedModels: currentPolicy.modelRestrictions.allowedModels,
         allowedProviders: currentPolicy.modelRestrictions.allowedProviders,
         defaultModel: currentPolicy.modelRestrictions.defaultModel,
         maxTokensPerRequest: currentPolicy.modelRestrictions.maxTokensPerRequest,
+        rewriteDeprecatedModels: currentPolicy.modelRestrictions.rewriteDeprecatedModels,
+        allowedEmbeddingModels: currentPolicy.modelRestrictions.allowedEmbeddingModels,
+        maxEmbeddingDimensions: currentPolicy.modelRestrictions.maxEmbeddingDimensions,
+        maxEmbeddingInputs: currentPolicy.modelRestrictions.maxEmbeddingInputs,
       },
       mcpPolicies: {
         enabled: currentPolicy.mcpPolicies.enabled,
         allowToolSearch: currentPolicy.mcpPolicies.allowToolSearch,
         auditToolExecution: currentPolicy.mcpPolicies.auditToolExecution,
+        listDeniedTools: currentPolicy.mcpPolicies.listDeniedTools,
       },
       cachingPolicy: {
         enabled: currentPolicy.cachingPolicy.enabled,
@@ -853,11 +945,13 @@ function EditPolicyDialog({
         excludedModels: currentPolicy.cachingPolicy.excludedModels,
         excludedPatterns: currentPolicy.cachingPolicy.excludedPatterns,
         trackSavings: currentPolicy.cachingPolicy.trackSavings,
+        allowBypass: currentPolicy.cachingPolicy.allowBypass,
       },
       routingPolicy: {
         enabled: currentPolicy.routingPolicy.enabled,
         strategy: currentPolicy.routingPolicy.strategy || null,
         allowModelOverride: currentPolicy.routingPolicy.allowModelOverride,
+        capabilityFallback: currentPolicy.routingPolicy.capabilityFallback,
       },
       resiliencePolicy: {
         enabled: currentPolicy.resiliencePolicy.enabled,
@@ -876,6 +970,7 @@ function EditPolicyDialog({
         circuitBreakerThreshold: currentPolicy.resiliencePolicy.circuitBreakerThreshold,
         circuitBreakerTimeout: currentPolicy.resiliencePolicy.circuitBreakerTimeout,
         requestTimeoutMs: currentPolicy.resiliencePolicy.requestTimeoutMs,
+        contextWindow: currentPolicy.resiliencePolicy.contextWindow,
       },
       budgetPolicy: {
         enabled: currentPolicy.budgetPolicy.enabled,
@@ -883,6 +978,8 @@ function EditPolicyDialog({
         weeklyLimitUSD: currentPolicy.budgetPolicy.weeklyLimitUSD,
         monthlyLimitUSD: currentPolicy.budgetPolicy.monthlyLimitUSD,
         maxCostPerRequest: currentPolicy.budgetPolicy.maxCostPerRequest,
+        maxTokensPerConversation: currentPolicy.budgetPolicy.maxTokensPerConversation,
+        maxCostPerConversation: currentPolicy.budgetPolicy.maxCostPerConversation,
         alertThreshold: currentPolicy.budgetPolicy.alertThreshold,
         criticalThreshold: currentPolicy.budgetPolicy.criticalThreshold,
         alertWebhook: currentPolicy.budgetPolicy.alertWebhook,
@@ -1162,3 +1259,193 @@ function ModelSelector({
     </Card>
   )
 }
+
+interface DryRunResult {
+  allowed: boolean
+  checks: { role: string | null; policy: string; outcome: string; code: string | null; message: string | null }[]
+  tools: { name: string; status: string; reason: string | null }[]
+  removedTools: string[]
+}
+
+const outcomeVariants: Record<string, 'success' | 'destructive' | 'warning' | 'secondary'> = {
+  pass: 'success',
+  block: 'destructive',
+  modify: 'warning',
+  skip: 'secondary',
+}
+
+// Policy Dry Run Dialog - Evaluates a hypothetical request against the role's
+// policies without sending it
+function DryRunDialog({
+  role,
+  open,
+  onOpenChange,
+  availableModels,
+}: {
+  role: Role
+  open: boolean
+  onOpenChange: (open: boolean) => void
+  availableModels: any[]
+}) {
+  const [model, setModel] = useState(role.policy?.modelRestrictions?.defaultModel || availableModels[0]?.id || '')
+  const [systemPrompt, setSystemPrompt] = useState('')
+  const [prompt, setPrompt] = useState('')
+  const [tools, setTools] = useState('')
+  const [dryRun, { data, loading, error }] = useMutation<{ dryRunPolicy: DryRunResult }>(DRY_RUN_POLICY)
+
+  const handleRun = () => {
+    const messages = [
+      ...(systemPrompt ? [{ role: 'system', content: systemPrompt }] : []),
+      { role: 'user', content: prompt },
+    ]
+    const toolNames = tools.split(',').map((t) => t.trim()).filter(Boolean)
+    dryRun({
+      variables: {
+        input: {
+          roleId: role.id,
+          model,
+          messages,
+          tools: toolNames.length > 0 ? toolNames.map((name) => ({ name })) : undefined,
+        },
+      },
+    }).catch(() => {}) // Shown from error
+  }
+
+  const result = data?.dryRunPolicy
+
+  return (
+    <Dialog open={open} onOpenChange={onOpenChange}>
+      <DialogContent className="max-w-3xl max-h-[90vh] overflow-y-auto">
+        <DialogHeader>
+          <DialogTitle>Dry Run: {role.name}</DialogTitle>
+          <DialogDescription>
+            See how this role's policies would treat a request, without sending it
+          </DialogDescription>
+        </DialogHeader>
+
+        <div className="space-y-4">
+          <div className="space-y-2">
+            <label className="text-sm font-medium">Model</label>
+            <Select value={model} onValueChange={setModel}>
+              <SelectTrigger>
+                <SelectValue placeholder="Select a model" />
+              </SelectTrigger>
+              <SelectContent>
+                {availableModels.map((m: any) => (
+                  <SelectItem key={m.id} value={m.id}>
+                    {m.name || m.id}
+                  </SelectItem>
+                ))}
+              </SelectContent>
+            </Select>
+          </div>
+          <div className="space-y-2">
+            <label className="text-sm font-medium">System Prompt</label>
+            <Textarea
+              value={systemPrompt}
+              onChange={(e) => setSystemPrompt(e.target.value)}
+              placeholder="Optional"
+              rows={2}
+            />
+          </div>
+          <div className="space-y-2">
+            <label className="text-sm font-medium">User Message</label>
+            <Textarea
+              value={prompt}
+              onChange={(e) => setPrompt(e.target.value)}
+              placeholder="The prompt to evaluate"
+              rows={4}
+            />
+          </div>
+          <div className="space-y-2">
+            <label className="text-sm font-medium">Tools</label>
+            <Input
+              value={tools}
+              onChange={(e) => setTools(e.target.value)}
+              placeholder="Comma-separated tool names, e.g. read_file, run_query"
+            />
+          </div>
+
+          {error && (
+            <div className="rounded-lg bg-destructive/10 p-3 text-sm text-destructive">
+              Dry run failed: {error.message}
+            </div>
+          )}
+
+          {result && (
+            <div className="space-y-4">
+              <div className="flex items-center gap-2">
+                <span className="text-sm font-medium">Result:</span>
+                {result.allowed ? (
+                  <Badge variant="success">Allowed</Badge>
+                ) : (
+                  <Badge variant="destructive">Blocked</Badge>
+                )}
+              </div>
+
+              <Table>
+                <TableHeader>
+                  <TableRow>
+                    <TableHead>Policy</TableHead>
+                    <TableHead>Role</TableHead>
+                    <TableHead>Outcome</TableHead>
+                    <TableHead>Details</TableHead>
+                  </TableRow>
+                </TableHeader>
+                <TableBody>
+                  {result.checks.map((check, i) => (
+                    <TableRow key={i}>
+                      <TableCell className="font-medium">{check.policy}</TableCell>
+                      <TableCell className="text-muted-foreground">{check.role || '—'}</TableCell>
+                      <TableCell>
+                        <Badge variant={outcomeVariants[check.outcome] || 'outline'}>{check.outcome}</Badge>
+                      </TableCell>
+                      <TableCell className="text-sm text-muted-foreground">
+                        {[check.code, check.message].filter(Boolean).join(': ') || '—'}
+                      </TableCell>
+                    </TableRow>
+                  ))}
+                </TableBody>
+              </Table>
+
+              {result.tools.length > 0 && (
+                <Table>
+                  <TableHeader>
+                    <TableRow>
+                      <TableHead>Tool</TableHead>
+                      <TableHead>Status</TableHead>
+                      <TableHead>Reason</TableHead>
+                    </TableRow>
+                  </TableHeader>
+                  <TableBody>
+                    {result.tools.map((tool) => (
+                      <TableRow key={tool.name}>
+                        <TableCell className="font-medium">{tool.name}</TableCell>
+                        <TableCell>
+                          <Badge variant={result.removedTools.includes(tool.name) ? 'destructive' : 'outline'}>
+                            {tool.status}
+                          </Badge>
+                        </TableCell>
+                        <TableCell className="text-sm text-muted-foreground">{tool.reason || '—'}</TableCell>
+                      </TableRow>
+                    ))}
+                  </TableBody>
+                </Table>
+              )}
+            </div>
+          )}
+        </div>
+
+        <DialogFooter>
+          <Button variant="outline" onClick={() => onOpenChange(false)}>
+            Close
+          </Button>
+          <Button onClick={handleRun} disabled={loading || !model || !prompt}>
+            <Play className="mr-2 h-4 w-4" />
+            {loading ? 'Evaluating...' : 'Run'}
+          </Button>
+        </DialogFooter>
+      </DialogContent>
+    </Dialog>
+  )
+}
@@ -1,8 +1,139 @@
 import { useState } from 'react';
+import { useMutation, useQuery } from '@apollo/client';
 import { Card, CardContent, CardDescription, CardHeader, CardTitle } from '@/components/ui/card';
 import { Button } from '@/components/ui/button';
 import { Input } from '@/components/ui/input';
 import { Switch } from '@/components/ui/switch';
+import { Select, SelectContent, SelectItem, SelectTrigger, SelectValue } from '@/components/ui/select';
+import { Mail, RefreshCw } from 'lucide-react';
+import {
+  RELOAD_CONFIG,
+  GET_ROLES,
+  GET_DIGEST_SUBSCRIPTION,
+  UPDATE_DIGEST_SUBSCRIPTION,
+  SEND_USAGE_DIGEST,
+} from '@/graphql/operations';
+
+interface ConfigReloadResult {
+  changed: string[];
+  restartRequired: string[];
+  reloadedAt: string;
+}
+
+interface DigestSubscription {
+  frequency: 'OFF' | 'DAILY' | 'WEEKLY';
+  roleId: string | null;
+  lastSentAt: string | null;
+  emailEnabled: boolean;
+}
+
+// The current user's usage digest emails
+function UsageDigestCard() {
+  const { data, error } = useQuery<{ digestSubscription: DigestSubscription }>(GET_DIGEST_SUBSCRIPTION);
+  const { data: rolesData } = useQuery(GET_ROLES);
+  const [updateSubscription, { loading: updating }] = useMutation(UPDATE_DIGEST_SUBSCRIPTION, {
+    refetchQueries: [{ query: GET_DIGEST_SUBSCRIPTION }],
+  });
+  const [sendDigest, { loading: sending }] = useMutation(SEND_USAGE_DIGEST);
+
+  const subscription = data?.digestSubscription;
+  const roles: { id: string; name: string }[] = rolesData?.roles || [];
+
+  const handleChange = async (frequency: string, roleId: string | null) => {
+    try {
+      await updateSubscription({ variables: { input: { frequency, roleId } } });
+    } catch (err) {
+      console.error('Failed to update usage digest:', err);
+      alert('Failed to update usage digest: ' + (err as Error).message);
+    }
+  };
+
+  const handleSend = async () => {
+    try {
+      await sendDigest();
+      alert('Usage digest sent');
+    } catch (err) {
+      console.error('Failed to send usage digest:', err);
+      alert('Failed to send usage digest: ' + (err as Error).message);
+    }
+  };
+
+  return (
+    <Card>
+      <CardHeader>
+        <CardTitle>Usage Digest</CardTitle>
+        <CardDescription>
+          Email yourself a summary of spend, top models and API keys, error rate and budget burn
+        </CardDescription>
+      </CardHeader>
+      <CardContent className="space-y-4">
+        {error ? (
+          <p className="text-sm text-muted-foreground">Usage digests are unavailable: {error.message}</p>
+        ) : subscription ? (
+          <>
+            {!subscription.emailEnabled && (
+              <p className="text-sm text-amber-600">
+                Email is not configured on the server, so no digests are sent.
+              </p>
+            )}
+            <div className="grid grid-cols-2 gap-4">
+              <div className="space-y-2">
+                <label className="text-sm font-medium">Frequency</label>
+                <Select
+                  value={subscription.frequency}
+                  onValueChange={(frequency) => handleChange(frequency, subscription.roleId)}
+                  disabled={updating}
+                >
+                  <SelectTrigger>
+                    <SelectValue />
+                  </SelectTrigger>
+                  <SelectContent>
+                    <SelectItem value="OFF">Off</SelectItem>
+                    <SelectItem value="DAILY">Daily</SelectItem>
+                    <SelectItem value="WEEKLY">Weekly</SelectItem>
+                  </SelectContent>
+                </Select>
+              </div>
+              <div className="space-y-2">
+                <label className="text-sm font-medium">Covers</label>
+                <Select
+                  value={subscription.roleId ?? 'all'}
+                  onValueChange={(roleId) => handleChange(subscription.frequency, roleId === 'all' ? null : roleId)}
+                  disabled={updating}
+                >
+                  <SelectTrigger>
+                    <SelectValue />
+                  </SelectTrigger>
+                  <SelectContent>
+                    <SelectItem value="all">Whole tenant</SelectItem>
+                    {roles.map((role) => (
+                      <SelectItem key={role.id} value={role.id}>
+                        {role.name}
+                      </SelectItem>
+                    ))}
+                  </SelectContent>
+                </Select>
+              </div>
+            </div>
+            <div className="flex items-center justify-between">
+              <p className="text-sm text-muted-foreground">
+                {subscription.lastSentAt
+                  ? `Last sent ${new Date(subscription.lastSentAt).toLocaleString()}`
+                  : 'No digest sent yet'}
+              </p>
+              <Button variant="outline" onClick={handleSend} disabled={sending || !subscription.emailEnabled}>
+                <Mail className="mr-2 h-4 w-4" />
+                {sending ? 'Sending...' : 'Send Now'}
+              </Button>
+            </div>
+          </>
+        ) : (
+          <p className="text-sm text-muted-foreground">Loading...</p>
+        )}
+      </CardContent>
+    </Card>
+  );
+}
 
 interface TenantSettings {
   rateLimitEnabled: boolean;
@@ -25,6 +156,18 @@ export default function Settings() {
     defaultModel: 'gpt-4o',
   });
   const [isSaving, setIsSaving] = useState(false);
+  const [reloadConfig, { loading: reloading }] = useMutation<{ reloadConfig: ConfigReloadResult }>(RELOAD_CONFIG);
+  const [reloadResult, setReloadResult] = useState<ConfigReloadResult | null>(null);
+
+  const handleReload = async () => {
+    try {
+      const { data } = await reloadConfig();
+      setReloadResult(data?.reloadConfig ?? null);
+    } catch (err) {
+      console.error('Failed to reload configuration:', err);
+      alert('Failed to reload configuration: ' + (err as Error).message);
+    }
+  };
 
   const handleSave = async () => {
     setIsSaving(true);
@@ -167,6 +310,37 @@ export default function Settings() {
             </div>
           </CardContent>
         </Card>
+
+        <UsageDigestCard />
+
+        {/* Server Configuration */}
+        <Card>
+          <CardHeader>
+            <CardTitle>Server Configuration</CardTitle>
+            <CardDescription>
+              Apply changes made to config.toml without restarting. In-flight requests are not interrupted.
+            </CardDescription>
+          </CardHeader>
+          <CardContent className="space-y-4">
+            <Button variant="outline" onClick={handleReload} disabled={reloading}>
+              <RefreshCw className="mr-2 h-4 w-4" />
+              {reloading ? 'Reloading...' : 'Reload Configuration'}
+            </Button>
+            {reloadResult && (
+              <div className="space-y-1 text-sm">
+                <p>
+                  Reloaded {new Date(reloadResult.reloadedAt).toLocaleString()}:{' '}
+                  {reloadResult.changed.length > 0 ? reloadResult.changed.join(', ') : 'no changes'}
+                </p>
+                {reloadResult.restartRequired.length > 0 && (
+                  <p className="text-amber-600">
+                    Restart required to apply: {reloadResult.restartRequired.join(', ')}
+                  </p>
+                )}
+              </div>
+            )}
+          </CardContent>
+        </Card>
       </div>
 
       <div className="flex justify-end">